@@ -0,0 +1,74 @@
+// Package admin holds the `weknora admin` command tree: operator tasks that
+// span a whole tenant or deployment rather than a single resource. Tenant
+// provisioning, re-embedding, vector-store reindex, KB export/import,
+// ingestion log tailing and declarative plan/apply live here. Health checks
+// stay on `weknora doctor`; KB creation and ingestion stay on
+// `weknora kb create` / `weknora doc upload`.
+package admin
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// listPageSize is the server batch used while enumerating a KB's documents.
+const listPageSize = 100
+
+// NewCmd builds the `weknora admin` parent command.
+func NewCmd(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "admin",
+		Short: "Operator tasks: tenants, re-embedding, reindex, export/import, plan/apply",
+		Long: `Administrative commands for operators of a WeKnora deployment.
+
+Most of these call tenant-admin or cross-tenant endpoints, so the active
+profile needs the matching permissions. Component health is covered by
+'weknora doctor'; knowledge base creation and ingestion by 'weknora kb create'
+and 'weknora doc upload'.`,
+	}
+	cmd.AddCommand(newCmdTenant(f))
+	cmd.AddCommand(NewCmdReembed(f))
+	cmd.AddCommand(NewCmdReindex(f))
+	cmd.AddCommand(NewCmdExport(f))
+	cmd.AddCommand(NewCmdImport(f))
+	cmd.AddCommand(NewCmdLogs(f))
+	cmd.AddCommand(NewCmdPlan(f))
+	cmd.AddCommand(NewCmdApply(f))
+	return cmd
+}
+
+// newCmdTenant builds the `weknora admin tenant` parent command.
+func newCmdTenant(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tenant",
+		Short: "Manage tenants (requires cross-tenant access)",
+	}
+	cmd.AddCommand(NewCmdTenantCreate(f))
+	cmd.AddCommand(NewCmdTenantList(f))
+	return cmd
+}
+
+// knowledgePager is the listing half of the SDK shared by reembed, export
+// and logs.
+type knowledgePager interface {
+	ListKnowledgeWithFilter(ctx context.Context, kbID string, page, pageSize int, filter sdk.KnowledgeListFilter) ([]sdk.Knowledge, int64, error)
+}
+
+// listAllKnowledge walks every server page of a KB's documents.
+func listAllKnowledge(ctx context.Context, svc knowledgePager, kbID string, filter sdk.KnowledgeListFilter) ([]sdk.Knowledge, error) {
+	var all []sdk.Knowledge
+	for page := 1; ; page++ {
+		items, total, err := svc.ListKnowledgeWithFilter(ctx, kbID, page, listPageSize, filter)
+		if err != nil {
+			return nil, cmdutil.WrapHTTP(err, "list documents")
+		}
+		all = append(all, items...)
+		if len(items) == 0 || int64(len(all)) >= total {
+			return all, nil
+		}
+	}
+}
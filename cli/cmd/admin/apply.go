@@ -0,0 +1,219 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+)
+
+type ApplyOptions struct {
+	File   string // positional: spec path, "-" for stdin
+	DryRun bool
+}
+
+const applySpecExample = `tenants:
+  - name: acme
+    description: ACME support
+models:
+  - name: bge-m3
+    type: Embedding
+    source: remote
+    parameters:
+      base_url: https://api.example.com/v1
+      api_key: ${EMBEDDING_API_KEY}
+knowledge_bases:
+  - name: handbook
+    embedding_model: bge-m3
+    chunking: {chunk_size: 800, chunk_overlap: 100}
+connectors:
+  - name: wiki
+    type: notion
+    knowledge_base: handbook
+    sync_schedule: "0 */6 * * *"
+    credentials: {token: ${NOTION_TOKEN}}
+retrieval:
+  embedding_top_k: 20
+  rerank_threshold: 0.3`
+
+// NewCmdPlan builds `weknora admin plan <file>`.
+func NewCmdPlan(f *cmdutil.Factory) *cobra.Command {
+	opts := &ApplyOptions{}
+	cmd := &cobra.Command{
+		Use:   "plan <file>",
+		Short: "Show what 'weknora admin apply' would change",
+		Long: `Compares a declarative YAML spec against the server and prints, per
+resource, whether apply would create it, update it (with the differing
+fields) or leave it unchanged. Nothing is written.
+
+See 'weknora admin apply --help' for the spec format.`,
+		Example: `  weknora admin plan weknora.yaml
+  weknora admin plan weknora.yaml --format json   # machine-readable diff for CI`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			fopts, err := cmdutil.CheckFormatFlag(c)
+			if err != nil {
+				return err
+			}
+			fopts.ResolveDefault(iostreams.IO.IsStdoutTTY())
+			opts.File = args[0]
+			spec, err := readApplySpec(opts.File)
+			if err != nil {
+				return err
+			}
+			cli, err := f.Client()
+			if err != nil {
+				return err
+			}
+			return runPlan(c.Context(), fopts, cli, spec)
+		},
+	}
+	cmdutil.AddFormatFlag(cmd, "changes", "warnings")
+	cmdutil.SetAgentHelp(cmd, cmdutil.AgentHelp{
+		UsedFor:       "Preview the changes a declarative spec would make before running 'weknora admin apply'.",
+		RequiredFlags: []string{"<file> (positional; - for stdin)"},
+		Output:        "envelope.data is {changes:[{kind, name, action: create|update|noop, fields, id}], warnings}",
+	})
+	return cmd
+}
+
+// NewCmdApply builds `weknora admin apply <file>`.
+func NewCmdApply(f *cmdutil.Factory) *cobra.Command {
+	opts := &ApplyOptions{}
+	cmd := &cobra.Command{
+		Use:   "apply <file>",
+		Short: "Reconcile tenants, models, KBs, connectors and retrieval config with a YAML spec",
+		Long: `Reads a declarative YAML spec and creates or updates resources until the
+server matches it. Resources are matched by name (models by name and type);
+fields left out of the spec are not managed, and nothing is ever deleted.
+Run 'weknora admin plan' first to review the changes.
+
+Sections are applied in order: tenants, models, knowledge_bases, connectors,
+retrieval. Tenants are provisioned cross-tenant; every other section targets
+the tenant of the active profile. ${VAR} references are expanded from the
+environment so secrets can stay out of the file. Secrets (model api_key /
+app_secret, connector credentials) are only sent on create.
+
+Apply stops at the first failing change; re-running it is safe.
+
+Spec:
+
+` + applySpecExample,
+		Example: `  weknora admin apply weknora.yaml
+  weknora admin apply weknora.yaml --dry-run   # validate the file without calling the server`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			fopts, err := cmdutil.CheckFormatFlag(c)
+			if err != nil {
+				return err
+			}
+			fopts.ResolveDefault(iostreams.IO.IsStdoutTTY())
+			opts.File = args[0]
+			spec, err := readApplySpec(opts.File)
+			if err != nil {
+				return err
+			}
+			if handled, err := cmdutil.HandleDryRun(c, opts.DryRun, cmdutil.DryRunPlan{
+				Action: "admin.apply",
+				Args: map[string]any{
+					"file":            opts.File,
+					"tenants":         len(spec.Tenants),
+					"models":          len(spec.Models),
+					"knowledge_bases": len(spec.KnowledgeBases),
+					"connectors":      len(spec.Connectors),
+					"retrieval_keys":  len(spec.Retrieval),
+				},
+			}); handled {
+				return err
+			}
+			cli, err := f.Client()
+			if err != nil {
+				return err
+			}
+			return runApply(c.Context(), fopts, cli, spec)
+		},
+	}
+	cmdutil.AddFormatFlag(cmd, "changes", "warnings")
+	cmdutil.AddDryRunFlag(cmd, &opts.DryRun)
+	cmdutil.SetAgentHelp(cmd, cmdutil.AgentHelp{
+		UsedFor:       "Reconcile a WeKnora environment with a version-controlled YAML spec (GitOps).",
+		RequiredFlags: []string{"<file> (positional; - for stdin)"},
+		Examples:      []string{"weknora admin apply weknora.yaml --format json"},
+		Output:        "envelope.data is the executed plan: {changes:[{kind, name, action, fields, id, error}], warnings}; exit 1 after the first failed change",
+		Warnings: []string{
+			"Never deletes resources. Embedding/summary models of existing KBs and connector types cannot be changed; plan reports these as warnings.",
+			"--dry-run only validates the file; use 'weknora admin plan' for a server-side diff.",
+		},
+	})
+	return cmd
+}
+
+func runPlan(ctx context.Context, fopts *cmdutil.FormatOptions, svc ApplyService, spec *applySpec) error {
+	plan, _, err := buildApplyPlan(ctx, svc, spec)
+	if err != nil {
+		return err
+	}
+	if fopts.WantsJSON() {
+		return fopts.Emit(iostreams.IO.Out, plan, nil)
+	}
+	printApplyPlan(iostreams.IO.Out, plan)
+	fmt.Fprintf(iostreams.IO.Out, "\nPlan: %d to create, %d to update, %d unchanged.\n",
+		plan.count(actionCreate), plan.count(actionUpdate), plan.count(actionNoop))
+	return nil
+}
+
+func runApply(ctx context.Context, fopts *cmdutil.FormatOptions, svc ApplyService, spec *applySpec) error {
+	plan, refs, err := buildApplyPlan(ctx, svc, spec)
+	if err != nil {
+		return err
+	}
+	applyErr := executeApplyPlan(ctx, svc, plan, refs)
+
+	if fopts.WantsJSON() {
+		if err := fopts.Emit(iostreams.IO.Out, plan, nil); err != nil {
+			return err
+		}
+		if applyErr != nil {
+			return &cmdutil.Error{Code: cmdutil.CodeOperationFailed, Message: applyErr.Error(), Silent: true}
+		}
+		return nil
+	}
+	printApplyPlan(iostreams.IO.Out, plan)
+	if applyErr != nil {
+		return applyErr
+	}
+	fmt.Fprintf(iostreams.IO.Out, "\nApply complete: %d created, %d updated, %d unchanged.\n",
+		plan.count(actionCreate), plan.count(actionUpdate), plan.count(actionNoop))
+	return nil
+}
+
+// printApplyPlan renders one line per resource (+ create, ~ update,
+// = unchanged) with the differing fields of updates indented below.
+func printApplyPlan(w io.Writer, plan *applyPlan) {
+	if len(plan.Changes) == 0 {
+		fmt.Fprintln(w, "(spec declares no resources)")
+	}
+	for _, c := range plan.Changes {
+		marker, label := "=", "no change"
+		switch c.Action {
+		case actionCreate:
+			marker, label = "+", "create"
+		case actionUpdate:
+			marker, label = "~", "update"
+		}
+		if c.Error != "" {
+			label = "FAIL: " + c.Error
+		}
+		fmt.Fprintf(w, "%s %-14s %s  (%s)\n", marker, c.Kind, c.Name, label)
+		for _, field := range c.Fields {
+			fmt.Fprintf(w, "      %s\n", field)
+		}
+	}
+	for _, warning := range plan.Warnings {
+		fmt.Fprintf(w, "warning: %s\n", warning)
+	}
+}
@@ -0,0 +1,593 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// Plan actions. noop entries are kept in the plan so the output accounts
+// for every declared resource.
+const (
+	actionCreate = "create"
+	actionUpdate = "update"
+	actionNoop   = "noop"
+)
+
+// Resource kinds, in apply order: later kinds reference earlier ones.
+const (
+	kindTenant        = "tenant"
+	kindModel         = "model"
+	kindKnowledgeBase = "knowledge_base"
+	kindConnector     = "connector"
+	kindRetrieval     = "retrieval"
+)
+
+const retrievalConfigKey = "retrieval-config"
+
+// secretModelParams are write-only on the server and never diffed.
+var secretModelParams = []string{"api_key", "app_secret"}
+
+// ApplyService is the narrow SDK surface `admin plan` / `admin apply`
+// depend on. *sdk.Client satisfies it.
+type ApplyService interface {
+	ListAllTenants(ctx context.Context) ([]sdk.Tenant, error)
+	CreateTenant(ctx context.Context, tenant *sdk.Tenant) (*sdk.Tenant, error)
+	UpdateTenant(ctx context.Context, tenant *sdk.Tenant) (*sdk.Tenant, error)
+	ListModels(ctx context.Context) ([]sdk.Model, error)
+	CreateModel(ctx context.Context, request *sdk.CreateModelRequest) (*sdk.Model, error)
+	UpdateModel(ctx context.Context, modelID string, request *sdk.UpdateModelRequest) (*sdk.Model, error)
+	ListKnowledgeBases(ctx context.Context) ([]sdk.KnowledgeBase, error)
+	CreateKnowledgeBase(ctx context.Context, kb *sdk.KnowledgeBase) (*sdk.KnowledgeBase, error)
+	UpdateKnowledgeBase(ctx context.Context, kbID string, request *sdk.UpdateKnowledgeBaseRequest) (*sdk.KnowledgeBase, error)
+	ListDataSources(ctx context.Context, kbID string) ([]sdk.DataSource, error)
+	CreateDataSource(ctx context.Context, ds *sdk.DataSource) (*sdk.DataSource, error)
+	UpdateDataSource(ctx context.Context, ds *sdk.DataSource) (*sdk.DataSource, error)
+	GetTenantKV(ctx context.Context, key string) (json.RawMessage, error)
+	UpdateTenantKV(ctx context.Context, key string, value any) (json.RawMessage, error)
+}
+
+// applyChange is one resource of the plan. Fields lists the differing
+// fields of an update as "path: current → desired".
+type applyChange struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	Action string   `json:"action"`
+	Fields []string `json:"fields,omitempty"`
+	ID     string   `json:"id,omitempty"`
+	Error  string   `json:"error,omitempty"`
+
+	run func(ctx context.Context, svc ApplyService, refs *applyRefs) (id string, err error)
+}
+
+type applyPlan struct {
+	Changes  []*applyChange `json:"changes"`
+	Warnings []string       `json:"warnings,omitempty"`
+}
+
+func (p *applyPlan) count(action string) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// applyRefs resolves names to server IDs. Existing resources are filled in
+// while planning; created ones as apply progresses.
+type applyRefs struct {
+	models map[string]string // "<type>/<name>" → id
+	kbs    map[string]string // name → id
+}
+
+func modelRef(modelType sdk.ModelType, name string) string {
+	return string(modelType) + "/" + name
+}
+
+// buildApplyPlan reads the current state of every section present in spec
+// and diffs it against the declared state. Sections absent from spec are
+// not read, so e.g. a spec without tenants needs no cross-tenant access.
+func buildApplyPlan(ctx context.Context, svc ApplyService, spec *applySpec) (*applyPlan, *applyRefs, error) {
+	plan := &applyPlan{}
+	refs := &applyRefs{models: map[string]string{}, kbs: map[string]string{}}
+
+	if len(spec.Tenants) > 0 {
+		if err := planTenants(ctx, svc, spec, plan); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(spec.Models) > 0 || len(spec.KnowledgeBases) > 0 {
+		if err := planModels(ctx, svc, spec, plan, refs); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(spec.KnowledgeBases) > 0 || len(spec.Connectors) > 0 {
+		if err := planKnowledgeBases(ctx, svc, spec, plan, refs); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(spec.Connectors) > 0 {
+		if err := planConnectors(ctx, svc, spec, plan, refs); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(spec.Retrieval) > 0 {
+		if err := planRetrieval(ctx, svc, spec, plan); err != nil {
+			return nil, nil, err
+		}
+	}
+	return plan, refs, nil
+}
+
+func planTenants(ctx context.Context, svc ApplyService, spec *applySpec, plan *applyPlan) error {
+	tenants, err := svc.ListAllTenants(ctx)
+	if err != nil {
+		return cmdutil.WrapHTTP(err, "list tenants")
+	}
+	byName := make(map[string]sdk.Tenant, len(tenants))
+	for _, t := range tenants {
+		byName[t.Name] = t
+	}
+	for _, want := range spec.Tenants {
+		change := &applyChange{Kind: kindTenant, Name: want.Name}
+		cur, ok := byName[want.Name]
+		if !ok {
+			change.Action = actionCreate
+			change.run = func(ctx context.Context, svc ApplyService, _ *applyRefs) (string, error) {
+				created, err := svc.CreateTenant(ctx, &sdk.Tenant{
+					Name:        want.Name,
+					Description: deref(want.Description),
+					Business:    deref(want.Business),
+				})
+				if err != nil {
+					return "", err
+				}
+				return fmt.Sprint(created.ID), nil
+			}
+			plan.Changes = append(plan.Changes, change)
+			continue
+		}
+		change.ID = fmt.Sprint(cur.ID)
+		diffPtr(&change.Fields, "description", cur.Description, want.Description)
+		diffPtr(&change.Fields, "business", cur.Business, want.Business)
+		change.Action = actionFor(change.Fields)
+		change.run = func(ctx context.Context, svc ApplyService, _ *applyRefs) (string, error) {
+			next := cur
+			next.Description = valueOr(want.Description, cur.Description)
+			next.Business = valueOr(want.Business, cur.Business)
+			_, err := svc.UpdateTenant(ctx, &next)
+			return change.ID, err
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+	return nil
+}
+
+func planModels(ctx context.Context, svc ApplyService, spec *applySpec, plan *applyPlan, refs *applyRefs) error {
+	models, err := svc.ListModels(ctx)
+	if err != nil {
+		return cmdutil.WrapHTTP(err, "list models")
+	}
+	byRef := make(map[string]sdk.Model, len(models))
+	for _, m := range models {
+		byRef[modelRef(m.Type, m.Name)] = m
+		refs.models[modelRef(m.Type, m.Name)] = m.ID
+		// Knowledge bases may also reference a model by ID.
+		refs.models[modelRef(m.Type, m.ID)] = m.ID
+	}
+	for _, want := range spec.Models {
+		modelType := sdk.ModelType(want.Type)
+		change := &applyChange{Kind: kindModel, Name: want.Name}
+		cur, ok := byRef[modelRef(modelType, want.Name)]
+		if !ok {
+			change.Action = actionCreate
+			change.run = func(ctx context.Context, svc ApplyService, refs *applyRefs) (string, error) {
+				created, err := svc.CreateModel(ctx, &sdk.CreateModelRequest{
+					Name:        want.Name,
+					DisplayName: deref(want.DisplayName),
+					Type:        modelType,
+					Source:      sdk.ModelSource(want.Source),
+					Description: deref(want.Description),
+					Parameters:  sdk.ModelParameters(want.Parameters),
+					IsDefault:   want.IsDefault != nil && *want.IsDefault,
+				})
+				if err != nil {
+					return "", err
+				}
+				refs.models[modelRef(modelType, want.Name)] = created.ID
+				return created.ID, nil
+			}
+			plan.Changes = append(plan.Changes, change)
+			continue
+		}
+		change.ID = cur.ID
+		if string(cur.Source) != want.Source {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"model %q: source %s cannot be changed in place (declared %s); skipped", want.Name, cur.Source, want.Source))
+		}
+		diffPtr(&change.Fields, "display_name", cur.DisplayName, want.DisplayName)
+		diffPtr(&change.Fields, "description", cur.Description, want.Description)
+		diffPtr(&change.Fields, "is_default", cur.IsDefault, want.IsDefault)
+		wantParams := withoutKeys(want.Parameters, secretModelParams)
+		change.Fields = append(change.Fields, subsetDiff("parameters", wantParams, cur.Parameters)...)
+		change.Action = actionFor(change.Fields)
+		change.run = func(ctx context.Context, svc ApplyService, _ *applyRefs) (string, error) {
+			params := maps.Clone(cur.Parameters)
+			if params == nil {
+				params = sdk.ModelParameters{}
+			}
+			maps.Copy(params, wantParams)
+			_, err := svc.UpdateModel(ctx, cur.ID, &sdk.UpdateModelRequest{
+				Name:        cur.Name,
+				DisplayName: valueOr(want.DisplayName, cur.DisplayName),
+				Description: valueOr(want.Description, cur.Description),
+				Parameters:  params,
+				IsDefault:   valueOr(want.IsDefault, cur.IsDefault),
+			})
+			return cur.ID, err
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+	return nil
+}
+
+func planKnowledgeBases(ctx context.Context, svc ApplyService, spec *applySpec, plan *applyPlan, refs *applyRefs) error {
+	kbs, err := svc.ListKnowledgeBases(ctx)
+	if err != nil {
+		return cmdutil.WrapHTTP(err, "list knowledge bases")
+	}
+	byName := make(map[string]sdk.KnowledgeBase, len(kbs))
+	for _, kb := range kbs {
+		byName[kb.Name] = kb
+		refs.kbs[kb.Name] = kb.ID
+	}
+	declaredModels := map[string]bool{}
+	for _, m := range spec.Models {
+		declaredModels[modelRef(sdk.ModelType(m.Type), m.Name)] = true
+	}
+	checkModel := func(kbName string, modelType sdk.ModelType, name string) error {
+		if name == "" || declaredModels[modelRef(modelType, name)] {
+			return nil
+		}
+		if _, ok := refs.models[modelRef(modelType, name)]; ok {
+			return nil
+		}
+		return cmdutil.NewError(cmdutil.CodeInputInvalidArgument, fmt.Sprintf(
+			"knowledge_base %q: %s model %q is neither declared nor present on the server", kbName, modelType, name))
+	}
+
+	for _, want := range spec.KnowledgeBases {
+		if err := checkModel(want.Name, sdk.ModelTypeEmbedding, want.EmbeddingModel); err != nil {
+			return err
+		}
+		if err := checkModel(want.Name, sdk.ModelTypeKnowledgeQA, want.SummaryModel); err != nil {
+			return err
+		}
+		change := &applyChange{Kind: kindKnowledgeBase, Name: want.Name}
+		cur, ok := byName[want.Name]
+		if !ok {
+			change.Action = actionCreate
+			change.run = func(ctx context.Context, svc ApplyService, refs *applyRefs) (string, error) {
+				req := &sdk.KnowledgeBase{
+					Name:           want.Name,
+					Description:    deref(want.Description),
+					ChunkingConfig: mergeChunking(sdk.ChunkingConfig{}, want.Chunking),
+				}
+				if want.EmbeddingModel != "" {
+					req.EmbeddingModelID = refs.models[modelRef(sdk.ModelTypeEmbedding, want.EmbeddingModel)]
+				}
+				if want.SummaryModel != "" {
+					req.SummaryModelID = refs.models[modelRef(sdk.ModelTypeKnowledgeQA, want.SummaryModel)]
+				}
+				created, err := svc.CreateKnowledgeBase(ctx, req)
+				if err != nil {
+					return "", err
+				}
+				refs.kbs[want.Name] = created.ID
+				return created.ID, nil
+			}
+			plan.Changes = append(plan.Changes, change)
+			continue
+		}
+
+		change.ID = cur.ID
+		if want.EmbeddingModel != "" && refs.models[modelRef(sdk.ModelTypeEmbedding, want.EmbeddingModel)] != cur.EmbeddingModelID {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"knowledge_base %q: embedding_model cannot be changed in place; skipped", want.Name))
+		}
+		if want.SummaryModel != "" && refs.models[modelRef(sdk.ModelTypeKnowledgeQA, want.SummaryModel)] != cur.SummaryModelID {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"knowledge_base %q: summary_model cannot be changed through apply; skipped", want.Name))
+		}
+		diffPtr(&change.Fields, "description", cur.Description, want.Description)
+		chunking := mergeChunking(cur.ChunkingConfig, want.Chunking)
+		if want.Chunking != nil {
+			diffPtr(&change.Fields, "chunking.chunk_size", cur.ChunkingConfig.ChunkSize, want.Chunking.ChunkSize)
+			diffPtr(&change.Fields, "chunking.chunk_overlap", cur.ChunkingConfig.ChunkOverlap, want.Chunking.ChunkOverlap)
+			if want.Chunking.Separators != nil && !slices.Equal(cur.ChunkingConfig.Separators, want.Chunking.Separators) {
+				change.Fields = append(change.Fields, diffLine("chunking.separators",
+					cur.ChunkingConfig.Separators, want.Chunking.Separators))
+			}
+		}
+		change.Action = actionFor(change.Fields)
+		change.run = func(ctx context.Context, svc ApplyService, _ *applyRefs) (string, error) {
+			req := &sdk.UpdateKnowledgeBaseRequest{
+				Name:        cur.Name,
+				Description: valueOr(want.Description, cur.Description),
+			}
+			if !reflect.DeepEqual(chunking, cur.ChunkingConfig) {
+				// The server replaces the whole config; carry the parts
+				// apply does not manage over unchanged.
+				req.Config = &sdk.KnowledgeBaseConfig{
+					ChunkingConfig:        chunking,
+					ImageProcessingConfig: cur.ImageProcessingConfig,
+					FAQConfig:             cur.FAQConfig,
+				}
+			}
+			_, err := svc.UpdateKnowledgeBase(ctx, cur.ID, req)
+			return cur.ID, err
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+	return nil
+}
+
+func planConnectors(ctx context.Context, svc ApplyService, spec *applySpec, plan *applyPlan, refs *applyRefs) error {
+	declaredKBs := map[string]bool{}
+	for _, kb := range spec.KnowledgeBases {
+		declaredKBs[kb.Name] = true
+	}
+	existing := map[string]map[string]sdk.DataSource{} // kb id → name → data source
+	for _, want := range spec.Connectors {
+		change := &applyChange{Kind: kindConnector, Name: want.Name}
+		kbID, kbExists := refs.kbs[want.KnowledgeBase]
+		if !kbExists && !declaredKBs[want.KnowledgeBase] {
+			return cmdutil.NewError(cmdutil.CodeInputInvalidArgument, fmt.Sprintf(
+				"connector %q: knowledge_base %q is neither declared nor present on the server", want.Name, want.KnowledgeBase))
+		}
+		var cur sdk.DataSource
+		found := false
+		if kbExists {
+			if _, listed := existing[kbID]; !listed {
+				sources, err := svc.ListDataSources(ctx, kbID)
+				if err != nil {
+					return cmdutil.WrapHTTP(err, "list connectors of knowledge base %s", want.KnowledgeBase)
+				}
+				byName := make(map[string]sdk.DataSource, len(sources))
+				for _, ds := range sources {
+					byName[ds.Name] = ds
+				}
+				existing[kbID] = byName
+			}
+			cur, found = existing[kbID][want.Name]
+		}
+
+		if !found {
+			change.Action = actionCreate
+			change.run = func(ctx context.Context, svc ApplyService, refs *applyRefs) (string, error) {
+				created, err := svc.CreateDataSource(ctx, &sdk.DataSource{
+					KnowledgeBaseID: refs.kbs[want.KnowledgeBase],
+					Name:            want.Name,
+					Type:            want.Type,
+					Config: &sdk.DataSourceConfig{
+						Type:        want.Type,
+						Credentials: want.Credentials,
+						ResourceIDs: want.ResourceIDs,
+						Settings:    want.Settings,
+					},
+					SyncSchedule:     deref(want.SyncSchedule),
+					SyncMode:         deref(want.SyncMode),
+					ConflictStrategy: deref(want.ConflictStrategy),
+					// The server defaults sync_deletions to true.
+					SyncDeletions: valueOr(want.SyncDeletions, true),
+				})
+				if err != nil {
+					return "", err
+				}
+				return created.ID, nil
+			}
+			plan.Changes = append(plan.Changes, change)
+			continue
+		}
+
+		change.ID = cur.ID
+		if cur.Type != want.Type {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf(
+				"connector %q: type %s cannot be changed in place (declared %s); skipped", want.Name, cur.Type, want.Type))
+		}
+		curConfig := sdk.DataSourceConfig{}
+		if cur.Config != nil {
+			curConfig = *cur.Config
+		}
+		diffPtr(&change.Fields, "sync_schedule", cur.SyncSchedule, want.SyncSchedule)
+		diffPtr(&change.Fields, "sync_mode", cur.SyncMode, want.SyncMode)
+		diffPtr(&change.Fields, "conflict_strategy", cur.ConflictStrategy, want.ConflictStrategy)
+		diffPtr(&change.Fields, "sync_deletions", cur.SyncDeletions, want.SyncDeletions)
+		if want.ResourceIDs != nil && !slices.Equal(curConfig.ResourceIDs, want.ResourceIDs) {
+			change.Fields = append(change.Fields, diffLine("resource_ids", curConfig.ResourceIDs, want.ResourceIDs))
+		}
+		change.Fields = append(change.Fields, subsetDiff("settings", want.Settings, curConfig.Settings)...)
+		change.Action = actionFor(change.Fields)
+		change.run = func(ctx context.Context, svc ApplyService, _ *applyRefs) (string, error) {
+			next := cur
+			config := curConfig
+			config.Credentials = nil // kept server-side; never sent on update
+			if want.ResourceIDs != nil {
+				config.ResourceIDs = want.ResourceIDs
+			}
+			if len(want.Settings) > 0 {
+				config.Settings = maps.Clone(config.Settings)
+				if config.Settings == nil {
+					config.Settings = map[string]any{}
+				}
+				maps.Copy(config.Settings, want.Settings)
+			}
+			next.Config = &config
+			next.SyncSchedule = valueOr(want.SyncSchedule, cur.SyncSchedule)
+			next.SyncMode = valueOr(want.SyncMode, cur.SyncMode)
+			next.ConflictStrategy = valueOr(want.ConflictStrategy, cur.ConflictStrategy)
+			next.SyncDeletions = valueOr(want.SyncDeletions, cur.SyncDeletions)
+			_, err := svc.UpdateDataSource(ctx, &next)
+			return cur.ID, err
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+	return nil
+}
+
+func planRetrieval(ctx context.Context, svc ApplyService, spec *applySpec, plan *applyPlan) error {
+	raw, err := svc.GetTenantKV(ctx, retrievalConfigKey)
+	if err != nil {
+		return cmdutil.WrapHTTP(err, "get retrieval config")
+	}
+	cur := map[string]any{}
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &cur); err != nil {
+			return cmdutil.Wrapf(cmdutil.CodeServerError, err, "decode retrieval config")
+		}
+	}
+	change := &applyChange{Kind: kindRetrieval, Name: retrievalConfigKey}
+	change.Fields = subsetDiff("", spec.Retrieval, cur)
+	change.Action = actionFor(change.Fields)
+	change.run = func(ctx context.Context, svc ApplyService, _ *applyRefs) (string, error) {
+		// The server replaces the whole config; merge so undeclared keys
+		// keep their current value.
+		merged := maps.Clone(cur)
+		maps.Copy(merged, spec.Retrieval)
+		_, err := svc.UpdateTenantKV(ctx, retrievalConfigKey, merged)
+		return "", err
+	}
+	plan.Changes = append(plan.Changes, change)
+	return nil
+}
+
+// executeApplyPlan runs every create/update in plan order and stops at the
+// first failure, which is recorded on the change and returned.
+func executeApplyPlan(ctx context.Context, svc ApplyService, plan *applyPlan, refs *applyRefs) error {
+	for _, change := range plan.Changes {
+		if change.Action == actionNoop {
+			continue
+		}
+		id, err := change.run(ctx, svc, refs)
+		if err != nil {
+			change.Error = err.Error()
+			return cmdutil.WrapHTTP(err, "%s %s %q", change.Action, change.Kind, change.Name)
+		}
+		if id != "" {
+			change.ID = id
+		}
+	}
+	return nil
+}
+
+func actionFor(fields []string) string {
+	if len(fields) == 0 {
+		return actionNoop
+	}
+	return actionUpdate
+}
+
+func deref[T any](p *T) T {
+	var zero T
+	if p == nil {
+		return zero
+	}
+	return *p
+}
+
+func valueOr[T any](p *T, fallback T) T {
+	if p == nil {
+		return fallback
+	}
+	return *p
+}
+
+// diffPtr records field when want is declared and differs from cur.
+func diffPtr[T comparable](fields *[]string, field string, cur T, want *T) {
+	if want != nil && *want != cur {
+		*fields = append(*fields, diffLine(field, cur, *want))
+	}
+}
+
+func diffLine(field string, cur, want any) string {
+	return fmt.Sprintf("%s: %s → %s", field, formatDiffValue(cur), formatDiffValue(want))
+}
+
+func formatDiffValue(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// subsetDiff lists the keys of want whose value differs from cur, recursing
+// into nested objects. Keys only present in cur are not managed and never
+// reported. Both sides are normalized through JSON so YAML ints compare
+// equal to the float64 numbers the server returns.
+func subsetDiff(prefix string, want, cur map[string]any) []string {
+	var fields []string
+	keys := slices.Collect(maps.Keys(want))
+	sort.Strings(keys)
+	for _, k := range keys {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		w, c := normalizeJSON(want[k]), normalizeJSON(cur[k])
+		wm, wIsMap := w.(map[string]any)
+		cm, cIsMap := c.(map[string]any)
+		if wIsMap && cIsMap {
+			fields = append(fields, subsetDiff(path, wm, cm)...)
+			continue
+		}
+		if !reflect.DeepEqual(w, c) {
+			fields = append(fields, diffLine(path, c, w))
+		}
+	}
+	return fields
+}
+
+func normalizeJSON(v any) any {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	if err := json.Unmarshal(b, &out); err != nil {
+		return v
+	}
+	return out
+}
+
+func withoutKeys(m map[string]any, drop []string) map[string]any {
+	out := make(map[string]any, len(m))
+	for k, v := range m {
+		if !slices.Contains(drop, k) {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+// mergeChunking overlays the declared chunking fields on base.
+func mergeChunking(base sdk.ChunkingConfig, want *chunkingSpec) sdk.ChunkingConfig {
+	if want == nil {
+		return base
+	}
+	base.ChunkSize = valueOr(want.ChunkSize, base.ChunkSize)
+	base.ChunkOverlap = valueOr(want.ChunkOverlap, base.ChunkOverlap)
+	if want.Separators != nil {
+		base.Separators = want.Separators
+	}
+	return base
+}
@@ -0,0 +1,178 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// applySpec is the declarative document read by `weknora admin plan` and
+// `weknora admin apply`. Every section is optional; resources are matched
+// by name and fields left out of the document are not managed.
+//
+// Tenants are provisioned cross-tenant. Every other section applies to the
+// tenant of the active profile.
+type applySpec struct {
+	Tenants        []tenantSpec        `yaml:"tenants"`
+	Models         []modelSpec         `yaml:"models"`
+	KnowledgeBases []knowledgeBaseSpec `yaml:"knowledge_bases"`
+	Connectors     []connectorSpec     `yaml:"connectors"`
+	// Retrieval is merged into the tenant's retrieval-config KV; keys
+	// not listed keep their current value.
+	Retrieval map[string]any `yaml:"retrieval"`
+}
+
+type tenantSpec struct {
+	Name        string  `yaml:"name"`
+	Description *string `yaml:"description"`
+	Business    *string `yaml:"business"`
+}
+
+// modelSpec declares a model. Credentials in Parameters (api_key,
+// app_secret) are only sent on create: the server never returns them, so
+// they cannot be diffed, and rotation goes through the credentials endpoint.
+type modelSpec struct {
+	Name        string         `yaml:"name"`
+	Type        string         `yaml:"type"`
+	Source      string         `yaml:"source"`
+	DisplayName *string        `yaml:"display_name"`
+	Description *string        `yaml:"description"`
+	Parameters  map[string]any `yaml:"parameters"`
+	IsDefault   *bool          `yaml:"is_default"`
+}
+
+// knowledgeBaseSpec declares a knowledge base. Models are referenced by
+// name, either declared in the same document or already on the server.
+type knowledgeBaseSpec struct {
+	Name           string        `yaml:"name"`
+	Description    *string       `yaml:"description"`
+	EmbeddingModel string        `yaml:"embedding_model"`
+	SummaryModel   string        `yaml:"summary_model"`
+	Chunking       *chunkingSpec `yaml:"chunking"`
+}
+
+type chunkingSpec struct {
+	ChunkSize    *int     `yaml:"chunk_size"`
+	ChunkOverlap *int     `yaml:"chunk_overlap"`
+	Separators   []string `yaml:"separators"`
+}
+
+// connectorSpec declares a data source bound to a knowledge base by name.
+// Like model secrets, Credentials are only sent on create.
+type connectorSpec struct {
+	Name             string         `yaml:"name"`
+	Type             string         `yaml:"type"`
+	KnowledgeBase    string         `yaml:"knowledge_base"`
+	SyncSchedule     *string        `yaml:"sync_schedule"`
+	SyncMode         *string        `yaml:"sync_mode"`
+	ConflictStrategy *string        `yaml:"conflict_strategy"`
+	SyncDeletions    *bool          `yaml:"sync_deletions"`
+	ResourceIDs      []string       `yaml:"resource_ids"`
+	Settings         map[string]any `yaml:"settings"`
+	Credentials      map[string]any `yaml:"credentials"`
+}
+
+// validModelTypes mirrors the server's model types.
+var validModelTypes = []sdk.ModelType{
+	sdk.ModelTypeEmbedding, sdk.ModelTypeRerank, sdk.ModelTypeKnowledgeQA, sdk.ModelTypeVLLM, sdk.ModelTypeASR,
+}
+
+func readApplySpec(path string) (*applySpec, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(iostreams.IO.In)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, cmdutil.Wrapf(cmdutil.CodeLocalFileIO, err, "read %s", path)
+	}
+	return parseApplySpec(data)
+}
+
+// parseApplySpec decodes and validates a spec. ${VAR} references are
+// expanded from the environment first so secrets can stay out of the file.
+// Unknown keys are rejected to catch typos that would otherwise be ignored.
+func parseApplySpec(data []byte) (*applySpec, error) {
+	expanded := os.ExpandEnv(string(data))
+	dec := yaml.NewDecoder(strings.NewReader(expanded))
+	dec.KnownFields(true)
+	spec := &applySpec{}
+	if err := dec.Decode(spec); err != nil && !errors.Is(err, io.EOF) {
+		return nil, cmdutil.NewError(cmdutil.CodeInputInvalidArgument, fmt.Sprintf("invalid spec: %v", err))
+	}
+	if err := spec.validate(); err != nil {
+		return nil, cmdutil.NewError(cmdutil.CodeInputInvalidArgument, fmt.Sprintf("invalid spec: %v", err))
+	}
+	return spec, nil
+}
+
+func (s *applySpec) validate() error {
+	var errs []string
+	seen := map[string]bool{}
+	checkName := func(kind, name string) {
+		if strings.TrimSpace(name) == "" {
+			errs = append(errs, kind+": name is required")
+			return
+		}
+		key := kind + "/" + name
+		if seen[key] {
+			errs = append(errs, fmt.Sprintf("%s %q is declared twice", kind, name))
+		}
+		seen[key] = true
+	}
+	for _, t := range s.Tenants {
+		checkName("tenant", t.Name)
+	}
+	for _, m := range s.Models {
+		checkName("model", m.Name)
+		if !isValidModelType(m.Type) {
+			errs = append(errs, fmt.Sprintf("model %q: type must be one of %s", m.Name, joinModelTypes()))
+		}
+		if m.Source == "" {
+			errs = append(errs, fmt.Sprintf("model %q: source is required", m.Name))
+		}
+	}
+	for _, kb := range s.KnowledgeBases {
+		checkName("knowledge_base", kb.Name)
+	}
+	for _, c := range s.Connectors {
+		checkName("connector", c.Name)
+		if c.Type == "" {
+			errs = append(errs, fmt.Sprintf("connector %q: type is required", c.Name))
+		}
+		if c.KnowledgeBase == "" {
+			errs = append(errs, fmt.Sprintf("connector %q: knowledge_base is required", c.Name))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func isValidModelType(t string) bool {
+	for _, v := range validModelTypes {
+		if string(v) == t {
+			return true
+		}
+	}
+	return false
+}
+
+func joinModelTypes() string {
+	names := make([]string, len(validModelTypes))
+	for i, v := range validModelTypes {
+		names[i] = string(v)
+	}
+	return strings.Join(names, " | ")
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+)
+
+func TestParseApplySpec_ExpandsEnv(t *testing.T) {
+	t.Setenv("EMBED_KEY", "sk-123")
+	spec, err := parseApplySpec([]byte(`
+models:
+  - name: bge-m3
+    type: Embedding
+    source: remote
+    parameters: {api_key: "${EMBED_KEY}"}
+`))
+	require.NoError(t, err)
+	require.Len(t, spec.Models, 1)
+	assert.Equal(t, "sk-123", spec.Models[0].Parameters["api_key"])
+}
+
+func TestParseApplySpec_Empty(t *testing.T) {
+	spec, err := parseApplySpec(nil)
+	require.NoError(t, err)
+	assert.Empty(t, spec.Tenants)
+}
+
+func TestParseApplySpec_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"unknown key":       "knowledge_bases:\n  - name: a\n    chunk_size: 10\n",
+		"missing name":      "tenants:\n  - description: x\n",
+		"duplicate":         "knowledge_bases:\n  - name: a\n  - name: a\n",
+		"bad model type":    "models:\n  - {name: m, type: Chat, source: remote}\n",
+		"connector orphan":  "connectors:\n  - {name: c, type: notion}\n",
+		"not a mapping doc": "- a\n- b\n",
+	}
+	for name, doc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseApplySpec([]byte(doc))
+			var typed *cmdutil.Error
+			require.ErrorAs(t, err, &typed)
+			assert.Equal(t, cmdutil.CodeInputInvalidArgument, typed.Code)
+		})
+	}
+}
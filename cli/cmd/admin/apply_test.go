@@ -0,0 +1,299 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// fakeApplySvc holds server state in memory and records writes.
+type fakeApplySvc struct {
+	tenants     []sdk.Tenant
+	models      []sdk.Model
+	kbs         []sdk.KnowledgeBase
+	dataSources []sdk.DataSource
+	kv          map[string]json.RawMessage
+
+	createKBErr error
+	writes      []string
+
+	createdKB     *sdk.KnowledgeBase
+	updatedModel  *sdk.UpdateModelRequest
+	updatedKB     *sdk.UpdateKnowledgeBaseRequest
+	createdSource *sdk.DataSource
+	updatedSource *sdk.DataSource
+	updatedKV     any
+}
+
+func (f *fakeApplySvc) ListAllTenants(context.Context) ([]sdk.Tenant, error) { return f.tenants, nil }
+
+func (f *fakeApplySvc) CreateTenant(_ context.Context, t *sdk.Tenant) (*sdk.Tenant, error) {
+	f.writes = append(f.writes, "create tenant "+t.Name)
+	return &sdk.Tenant{ID: 7, Name: t.Name}, nil
+}
+
+func (f *fakeApplySvc) UpdateTenant(_ context.Context, t *sdk.Tenant) (*sdk.Tenant, error) {
+	f.writes = append(f.writes, "update tenant "+t.Name)
+	return t, nil
+}
+
+func (f *fakeApplySvc) ListModels(context.Context) ([]sdk.Model, error) { return f.models, nil }
+
+func (f *fakeApplySvc) CreateModel(_ context.Context, r *sdk.CreateModelRequest) (*sdk.Model, error) {
+	f.writes = append(f.writes, "create model "+r.Name)
+	return &sdk.Model{ID: "model-new", Name: r.Name, Type: r.Type}, nil
+}
+
+func (f *fakeApplySvc) UpdateModel(_ context.Context, id string, r *sdk.UpdateModelRequest) (*sdk.Model, error) {
+	f.writes = append(f.writes, "update model "+id)
+	f.updatedModel = r
+	return &sdk.Model{ID: id}, nil
+}
+
+func (f *fakeApplySvc) ListKnowledgeBases(context.Context) ([]sdk.KnowledgeBase, error) {
+	return f.kbs, nil
+}
+
+func (f *fakeApplySvc) CreateKnowledgeBase(_ context.Context, kb *sdk.KnowledgeBase) (*sdk.KnowledgeBase, error) {
+	if f.createKBErr != nil {
+		return nil, f.createKBErr
+	}
+	f.writes = append(f.writes, "create knowledge_base "+kb.Name)
+	f.createdKB = kb
+	return &sdk.KnowledgeBase{ID: "kb-new", Name: kb.Name}, nil
+}
+
+func (f *fakeApplySvc) UpdateKnowledgeBase(_ context.Context, id string, r *sdk.UpdateKnowledgeBaseRequest) (*sdk.KnowledgeBase, error) {
+	f.writes = append(f.writes, "update knowledge_base "+id)
+	f.updatedKB = r
+	return &sdk.KnowledgeBase{ID: id}, nil
+}
+
+func (f *fakeApplySvc) ListDataSources(_ context.Context, kbID string) ([]sdk.DataSource, error) {
+	var out []sdk.DataSource
+	for _, ds := range f.dataSources {
+		if ds.KnowledgeBaseID == kbID {
+			out = append(out, ds)
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeApplySvc) CreateDataSource(_ context.Context, ds *sdk.DataSource) (*sdk.DataSource, error) {
+	f.writes = append(f.writes, "create connector "+ds.Name)
+	f.createdSource = ds
+	return &sdk.DataSource{ID: "ds-new"}, nil
+}
+
+func (f *fakeApplySvc) UpdateDataSource(_ context.Context, ds *sdk.DataSource) (*sdk.DataSource, error) {
+	f.writes = append(f.writes, "update connector "+ds.ID)
+	f.updatedSource = ds
+	return ds, nil
+}
+
+func (f *fakeApplySvc) GetTenantKV(_ context.Context, key string) (json.RawMessage, error) {
+	return f.kv[key], nil
+}
+
+func (f *fakeApplySvc) UpdateTenantKV(_ context.Context, key string, value any) (json.RawMessage, error) {
+	f.writes = append(f.writes, "update kv "+key)
+	f.updatedKV = value
+	return nil, nil
+}
+
+func mustSpec(t *testing.T, doc string) *applySpec {
+	t.Helper()
+	spec, err := parseApplySpec([]byte(doc))
+	require.NoError(t, err)
+	return spec
+}
+
+func textOpts() *cmdutil.FormatOptions { return &cmdutil.FormatOptions{Mode: cmdutil.FormatText} }
+
+func TestPlan_ClassifiesCreateUpdateNoop(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeApplySvc{
+		models: []sdk.Model{{
+			ID: "m1", Name: "bge-m3", Type: sdk.ModelTypeEmbedding, Source: "remote",
+			Parameters: sdk.ModelParameters{"base_url": "https://a", "dimension": float64(1024)},
+		}},
+		kbs: []sdk.KnowledgeBase{{
+			ID: "kb1", Name: "handbook", Description: "old", EmbeddingModelID: "m1",
+			ChunkingConfig: sdk.ChunkingConfig{ChunkSize: 800, ChunkOverlap: 100},
+		}},
+	}
+	spec := mustSpec(t, `
+models:
+  - name: bge-m3
+    type: Embedding
+    source: remote
+    parameters: {base_url: "https://a", dimension: 1024, api_key: secret}
+knowledge_bases:
+  - name: handbook
+    description: new
+    embedding_model: bge-m3
+    chunking: {chunk_size: 800}
+  - name: faq
+    embedding_model: bge-m3
+`)
+	require.NoError(t, runPlan(context.Background(), textOpts(), svc, spec))
+
+	assert.Empty(t, svc.writes, "plan must not write")
+	got := out.String()
+	assert.Contains(t, got, "= model")
+	assert.Contains(t, got, `description: "old" → "new"`)
+	assert.Contains(t, got, "+ knowledge_base")
+	assert.Contains(t, got, "Plan: 1 to create, 1 to update, 1 unchanged.")
+}
+
+func TestPlan_JSON(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeApplySvc{kv: map[string]json.RawMessage{
+		retrievalConfigKey: json.RawMessage(`{"embedding_top_k": 10, "rerank_top_k": 5}`),
+	}}
+	spec := mustSpec(t, "retrieval: {embedding_top_k: 20, rerank_top_k: 5}\n")
+	require.NoError(t, runPlan(context.Background(), &cmdutil.FormatOptions{Mode: cmdutil.FormatJSON}, svc, spec))
+
+	var env struct {
+		Data applyPlan `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &env))
+	require.Len(t, env.Data.Changes, 1)
+	assert.Equal(t, actionUpdate, env.Data.Changes[0].Action)
+	assert.Equal(t, []string{"embedding_top_k: 10 → 20"}, env.Data.Changes[0].Fields)
+}
+
+func TestPlan_UnknownModelReference(t *testing.T) {
+	iostreams.SetForTest(t)
+	spec := mustSpec(t, "knowledge_bases:\n  - {name: kb, embedding_model: missing}\n")
+	err := runPlan(context.Background(), textOpts(), &fakeApplySvc{}, spec)
+	var typed *cmdutil.Error
+	require.ErrorAs(t, err, &typed)
+	assert.Equal(t, cmdutil.CodeInputInvalidArgument, typed.Code)
+}
+
+func TestPlan_WarnsOnImmutableEmbeddingModel(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeApplySvc{
+		models: []sdk.Model{
+			{ID: "m1", Name: "old", Type: sdk.ModelTypeEmbedding},
+			{ID: "m2", Name: "new", Type: sdk.ModelTypeEmbedding},
+		},
+		kbs: []sdk.KnowledgeBase{{ID: "kb1", Name: "handbook", EmbeddingModelID: "m1"}},
+	}
+	spec := mustSpec(t, "knowledge_bases:\n  - {name: handbook, embedding_model: new}\n")
+	require.NoError(t, runPlan(context.Background(), textOpts(), svc, spec))
+	assert.Contains(t, out.String(), "embedding_model cannot be changed in place")
+}
+
+func TestApply_CreatesInDependencyOrder(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeApplySvc{}
+	spec := mustSpec(t, `
+tenants:
+  - name: acme
+models:
+  - {name: bge-m3, type: Embedding, source: remote}
+knowledge_bases:
+  - name: handbook
+    embedding_model: bge-m3
+    chunking: {chunk_size: 500, chunk_overlap: 50}
+connectors:
+  - name: wiki
+    type: notion
+    knowledge_base: handbook
+    credentials: {token: t}
+`)
+	require.NoError(t, runApply(context.Background(), textOpts(), svc, spec))
+
+	assert.Equal(t, []string{
+		"create tenant acme",
+		"create model bge-m3",
+		"create knowledge_base handbook",
+		"create connector wiki",
+	}, svc.writes)
+	assert.Equal(t, "model-new", svc.createdKB.EmbeddingModelID, "KB must reference the model created earlier in the run")
+	assert.Equal(t, 500, svc.createdKB.ChunkingConfig.ChunkSize)
+	assert.Equal(t, "kb-new", svc.createdSource.KnowledgeBaseID)
+	assert.Equal(t, "t", svc.createdSource.Config.Credentials["token"])
+	assert.True(t, svc.createdSource.SyncDeletions)
+	assert.Contains(t, out.String(), "Apply complete: 4 created, 0 updated, 0 unchanged.")
+}
+
+func TestApply_UpdatesPreserveUnmanagedFields(t *testing.T) {
+	iostreams.SetForTest(t)
+	svc := &fakeApplySvc{
+		models: []sdk.Model{{
+			ID: "m1", Name: "bge-m3", Type: sdk.ModelTypeEmbedding, Source: "remote",
+			Parameters: sdk.ModelParameters{"base_url": "https://a", "dimension": float64(1024)},
+		}},
+		kbs: []sdk.KnowledgeBase{{
+			ID: "kb1", Name: "handbook", EmbeddingModelID: "m1",
+			ChunkingConfig:        sdk.ChunkingConfig{ChunkSize: 800, ChunkOverlap: 100, Separators: []string{"\n"}},
+			ImageProcessingConfig: sdk.ImageProcessingConfig{ModelID: "vlm"},
+		}},
+		dataSources: []sdk.DataSource{{
+			ID: "ds1", KnowledgeBaseID: "kb1", Name: "wiki", Type: "notion", SyncSchedule: "0 * * * *",
+			Config: &sdk.DataSourceConfig{Type: "notion", ResourceIDs: []string{"p1"}, Settings: map[string]any{"depth": float64(2)}},
+		}},
+		kv: map[string]json.RawMessage{retrievalConfigKey: json.RawMessage(`{"embedding_top_k": 10, "rerank_top_k": 5}`)},
+	}
+	spec := mustSpec(t, `
+models:
+  - {name: bge-m3, type: Embedding, source: remote, parameters: {base_url: "https://b", api_key: rotated}}
+knowledge_bases:
+  - {name: handbook, chunking: {chunk_size: 600}}
+connectors:
+  - {name: wiki, type: notion, knowledge_base: handbook, sync_schedule: "0 */6 * * *", credentials: {token: x}}
+retrieval: {embedding_top_k: 20}
+`)
+	require.NoError(t, runApply(context.Background(), textOpts(), svc, spec))
+
+	require.NotNil(t, svc.updatedModel)
+	assert.Equal(t, "https://b", svc.updatedModel.Parameters["base_url"])
+	assert.Equal(t, float64(1024), svc.updatedModel.Parameters["dimension"])
+	assert.NotContains(t, svc.updatedModel.Parameters, "api_key", "secrets are only sent on create")
+
+	require.NotNil(t, svc.updatedKB.Config)
+	assert.Equal(t, sdk.ChunkingConfig{ChunkSize: 600, ChunkOverlap: 100, Separators: []string{"\n"}}, svc.updatedKB.Config.ChunkingConfig)
+	assert.Equal(t, "vlm", svc.updatedKB.Config.ImageProcessingConfig.ModelID)
+
+	require.NotNil(t, svc.updatedSource)
+	assert.Equal(t, "0 */6 * * *", svc.updatedSource.SyncSchedule)
+	assert.Equal(t, []string{"p1"}, svc.updatedSource.Config.ResourceIDs)
+	assert.Nil(t, svc.updatedSource.Config.Credentials)
+
+	assert.Equal(t, map[string]any{"embedding_top_k": 20, "rerank_top_k": float64(5)}, svc.updatedKV)
+}
+
+func TestApply_StopsAtFirstFailure(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeApplySvc{createKBErr: errors.New("boom")}
+	spec := mustSpec(t, `
+knowledge_bases:
+  - name: handbook
+connectors:
+  - {name: wiki, type: notion, knowledge_base: handbook}
+`)
+	err := runApply(context.Background(), &cmdutil.FormatOptions{Mode: cmdutil.FormatJSON}, svc, spec)
+
+	var typed *cmdutil.Error
+	require.ErrorAs(t, err, &typed)
+	assert.Equal(t, cmdutil.CodeOperationFailed, typed.Code)
+	assert.True(t, typed.Silent, "plan already on stdout; error envelope must be suppressed")
+	assert.Empty(t, svc.writes, "connector must not be created once its KB failed")
+
+	var env struct {
+		Data applyPlan `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &env))
+	assert.Contains(t, env.Data.Changes[0].Error, "boom")
+}
@@ -0,0 +1,202 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// Export record kinds. An export is NDJSON: one knowledge_base record
+// followed by one document record per document, each carrying its chunks.
+const (
+	exportKindKnowledgeBase = "knowledge_base"
+	exportKindDocument      = "document"
+)
+
+// exportRecord is one NDJSON line of an export file. Exactly one of
+// KnowledgeBase / Document is set, matching Kind.
+type exportRecord struct {
+	Kind          string             `json:"kind"`
+	KnowledgeBase *sdk.KnowledgeBase `json:"knowledge_base,omitempty"`
+	Document      *exportDocument    `json:"document,omitempty"`
+}
+
+// exportDocument is a document plus the chunks the server derived from it.
+type exportDocument struct {
+	sdk.Knowledge
+	Chunks []exportChunk `json:"chunks"`
+}
+
+// exportChunk keeps the chunk fields needed to rebuild a document's text;
+// ids and linkage are server-assigned and regenerated on import.
+type exportChunk struct {
+	ChunkIndex int    `json:"chunk_index"`
+	ChunkType  string `json:"chunk_type"`
+	Content    string `json:"content"`
+}
+
+type ExportOptions struct {
+	Output  string // --output / -O: target path, "-" for stdout
+	Clobber bool
+}
+
+// ExportService is the narrow SDK surface this command depends on.
+// *sdk.Client satisfies it.
+type ExportService interface {
+	knowledgePager
+	GetKnowledgeBase(ctx context.Context, kbID string) (*sdk.KnowledgeBase, error)
+	ListKnowledgeChunks(ctx context.Context, knowledgeID string, page, pageSize int, chunkTypes ...string) ([]sdk.Chunk, int64, error)
+}
+
+// exportSummary is the --format json payload when writing to a file.
+type exportSummary struct {
+	Path      string `json:"path"`
+	KBID      string `json:"kb_id"`
+	Documents int    `json:"documents"`
+	Chunks    int    `json:"chunks"`
+}
+
+// NewCmdExport builds `weknora admin export`.
+func NewCmdExport(f *cmdutil.Factory) *cobra.Command {
+	opts := &ExportOptions{}
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export a knowledge base's documents and chunks as NDJSON",
+		Long: `Writes the resolved knowledge base, its documents and their chunk text
+as NDJSON: a "knowledge_base" record followed by one "document" record per
+document. The file can be loaded into another deployment with
+'weknora admin import'.
+
+Embeddings and original uploaded files are not exported; imported documents
+are re-embedded by the target server.`,
+		Example: `  weknora admin export --kb my-kb -O my-kb.ndjson
+  weknora admin export --kb my-kb -O - | gzip > my-kb.ndjson.gz`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			fopts, err := cmdutil.CheckFormatFlag(c)
+			if err != nil {
+				return err
+			}
+			fopts.ResolveDefault(iostreams.IO.IsStdoutTTY())
+			if opts.Output == "" {
+				return cmdutil.NewFlagError(fmt.Errorf("--output is required (use - for stdout)"))
+			}
+			kbID, err := f.ResolveKB(c)
+			if err != nil {
+				return err
+			}
+			cli, err := f.Client()
+			if err != nil {
+				return err
+			}
+			if opts.Output == "-" {
+				// Records own stdout; no envelope is emitted.
+				_, err := runExport(c.Context(), cli, kbID, iostreams.IO.Out)
+				return err
+			}
+			return exportToFile(c.Context(), opts, fopts, cli, kbID)
+		},
+	}
+	cmdutil.AddKBFlag(cmd)
+	cmd.Flags().StringVarP(&opts.Output, "output", "O", "", `Output path; "-" for stdout`)
+	cmd.Flags().BoolVar(&opts.Clobber, "clobber", false, "Overwrite the output file if it exists")
+	cmdutil.AddFormatFlag(cmd, "path", "kb_id", "documents", "chunks")
+	cmdutil.SetAgentHelp(cmd, cmdutil.AgentHelp{
+		UsedFor:       "Back up or migrate a knowledge base's text content as NDJSON.",
+		RequiredFlags: []string{"--kb (or WEKNORA_KB_ID / project link)", "--output"},
+		Examples:      []string{"weknora admin export --kb my-kb -O my-kb.ndjson --format json"},
+		Output:        "with a file path and --format json: envelope.data has path, kb_id, documents, chunks; with -O - the raw NDJSON goes to stdout",
+	})
+	return cmd
+}
+
+func exportToFile(ctx context.Context, opts *ExportOptions, fopts *cmdutil.FormatOptions, svc ExportService, kbID string) error {
+	if !opts.Clobber {
+		if _, err := os.Stat(opts.Output); err == nil {
+			return &cmdutil.Error{
+				Code:    cmdutil.CodeInputInvalidArgument,
+				Message: fmt.Sprintf("%s already exists", opts.Output),
+				Hint:    "pass --clobber to overwrite",
+			}
+		}
+	}
+	file, err := os.Create(opts.Output)
+	if err != nil {
+		return cmdutil.Wrapf(cmdutil.CodeLocalFileIO, err, "create %s", opts.Output)
+	}
+	summary, err := runExport(ctx, svc, kbID, file)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = cmdutil.Wrapf(cmdutil.CodeLocalFileIO, closeErr, "close %s", opts.Output)
+	}
+	if err != nil {
+		_ = os.Remove(opts.Output)
+		return err
+	}
+	summary.Path = opts.Output
+
+	if fopts.WantsJSON() {
+		return fopts.Emit(iostreams.IO.Out, summary, nil)
+	}
+	fmt.Fprintf(iostreams.IO.Err, "✓ Exported %d document(s), %d chunk(s) to %s\n",
+		summary.Documents, summary.Chunks, opts.Output)
+	return nil
+}
+
+// runExport streams the export records for kbID to w.
+func runExport(ctx context.Context, svc ExportService, kbID string, w io.Writer) (*exportSummary, error) {
+	kb, err := svc.GetKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, cmdutil.WrapHTTP(err, "get knowledge base %s", kbID)
+	}
+	docs, err := listAllKnowledge(ctx, svc, kbID, sdk.KnowledgeListFilter{})
+	if err != nil {
+		return nil, err
+	}
+
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(exportRecord{Kind: exportKindKnowledgeBase, KnowledgeBase: kb}); err != nil {
+		return nil, cmdutil.Wrapf(cmdutil.CodeLocalFileIO, err, "write export")
+	}
+	summary := &exportSummary{KBID: kbID}
+	for _, d := range docs {
+		chunks, err := listAllChunks(ctx, svc, d.ID)
+		if err != nil {
+			return nil, err
+		}
+		rec := exportRecord{
+			Kind:     exportKindDocument,
+			Document: &exportDocument{Knowledge: d, Chunks: chunks},
+		}
+		if err := enc.Encode(rec); err != nil {
+			return nil, cmdutil.Wrapf(cmdutil.CodeLocalFileIO, err, "write export")
+		}
+		summary.Documents++
+		summary.Chunks += len(chunks)
+	}
+	return summary, nil
+}
+
+// listAllChunks walks every server page of a document's chunks.
+func listAllChunks(ctx context.Context, svc ExportService, knowledgeID string) ([]exportChunk, error) {
+	out := []exportChunk{}
+	for page := 1; ; page++ {
+		items, total, err := svc.ListKnowledgeChunks(ctx, knowledgeID, page, listPageSize)
+		if err != nil {
+			return nil, cmdutil.WrapHTTP(err, "list chunks of document %s", knowledgeID)
+		}
+		for _, c := range items {
+			out = append(out, exportChunk{ChunkIndex: c.ChunkIndex, ChunkType: c.ChunkType, Content: c.Content})
+		}
+		if len(items) == 0 || int64(len(out)) >= total {
+			return out, nil
+		}
+	}
+}
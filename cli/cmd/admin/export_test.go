@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+type fakeExportSvc struct {
+	fakeKnowledgePager
+	kb     *sdk.KnowledgeBase
+	chunks map[string][]sdk.Chunk
+}
+
+func (f *fakeExportSvc) GetKnowledgeBase(context.Context, string) (*sdk.KnowledgeBase, error) {
+	return f.kb, nil
+}
+
+func (f *fakeExportSvc) ListKnowledgeChunks(_ context.Context, id string, page, _ int, _ ...string) ([]sdk.Chunk, int64, error) {
+	all := f.chunks[id]
+	if page > 1 {
+		return nil, int64(len(all)), nil
+	}
+	return all, int64(len(all)), nil
+}
+
+func TestExport_RoundTripsThroughParseImport(t *testing.T) {
+	svc := &fakeExportSvc{
+		fakeKnowledgePager: fakeKnowledgePager{docs: []sdk.Knowledge{
+			{ID: "d1", Title: "Guide"},
+			{ID: "d2", FileName: "notes.md"},
+		}},
+		kb: &sdk.KnowledgeBase{ID: "kb1", Name: "Docs", Description: "team docs"},
+		chunks: map[string][]sdk.Chunk{
+			"d1": {{ChunkIndex: 1, ChunkType: "text", Content: "second"}, {ChunkIndex: 0, ChunkType: "text", Content: "first"}},
+			"d2": {{ChunkIndex: 0, ChunkType: "text", Content: "only"}, {ChunkIndex: 1, ChunkType: "summary", Content: "sum"}},
+		},
+	}
+	var buf bytes.Buffer
+	summary, err := runExport(context.Background(), svc, "kb1", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, 2, summary.Documents)
+	assert.Equal(t, 4, summary.Chunks)
+	assert.Equal(t, 3, bytes.Count(buf.Bytes(), []byte("\n")), "one kb record + one line per document")
+
+	bundle, err := parseImport(&buf)
+	require.NoError(t, err)
+	require.NotNil(t, bundle.knowledgeBase)
+	assert.Equal(t, "Docs", bundle.knowledgeBase.Name)
+	require.Len(t, bundle.documents, 2)
+	assert.Equal(t, "first\n\nsecond", documentText(bundle.documents[0]))
+	assert.Equal(t, "only", documentText(bundle.documents[1]), "derived chunks are dropped")
+	assert.Equal(t, "notes.md", documentTitle(bundle.documents[1]))
+}
@@ -0,0 +1,250 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+type ImportOptions struct {
+	Input string // positional: export file path, "-" for stdin
+	// KB is the raw --kb value (id or name). Empty means "create a new KB
+	// from the export's knowledge_base record".
+	KB     string
+	Name   string // --name: override the name of the newly created KB
+	DryRun bool
+}
+
+// ImportService is the narrow SDK surface this command depends on.
+// *sdk.Client satisfies it.
+type ImportService interface {
+	cmdutil.KBLister
+	CreateKnowledgeBase(ctx context.Context, kb *sdk.KnowledgeBase) (*sdk.KnowledgeBase, error)
+	CreateManualKnowledge(ctx context.Context, kbID string, req *sdk.CreateManualKnowledgeRequest) (*sdk.Knowledge, error)
+}
+
+// NewCmdImport builds `weknora admin import <file>`.
+func NewCmdImport(f *cmdutil.Factory) *cobra.Command {
+	opts := &ImportOptions{}
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import documents from a 'weknora admin export' file",
+		Long: `Loads an NDJSON file produced by 'weknora admin export'. Each exported
+document is recreated as a manual (Markdown) document whose content is the
+concatenation of its text chunks, then parsed and embedded by the server
+with the target KB's models. Text shared by overlapping chunks appears twice
+in the rebuilt content.
+
+Without --kb a new knowledge base is created from the export's
+knowledge_base record (name overridable with --name). Documents are imported
+one by one; per-document failures do not stop the run.`,
+		Example: `  weknora admin import my-kb.ndjson
+  weknora admin import my-kb.ndjson --kb existing-kb
+  gunzip -c my-kb.ndjson.gz | weknora admin import - --name restored-kb`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			fopts, err := cmdutil.CheckFormatFlag(c)
+			if err != nil {
+				return err
+			}
+			fopts.ResolveDefault(iostreams.IO.IsStdoutTTY())
+			opts.Input = args[0]
+			opts.KB, _ = c.Flags().GetString("kb")
+			bundle, err := readImportFile(opts.Input)
+			if err != nil {
+				return err
+			}
+			if handled, err := cmdutil.HandleDryRun(c, opts.DryRun, cmdutil.DryRunPlan{
+				Action: "admin.import",
+				Args: map[string]any{
+					"input":     opts.Input,
+					"kb":        opts.KB,
+					"name":      importKBName(opts, bundle),
+					"documents": len(bundle.documents),
+				},
+			}); handled {
+				return err
+			}
+			cli, err := f.Client()
+			if err != nil {
+				return err
+			}
+			return runImport(c.Context(), opts, fopts, cli, bundle)
+		},
+	}
+	cmd.Flags().String("kb", "", "Target knowledge base UUID or name (default: create a new one)")
+	cmd.Flags().StringVar(&opts.Name, "name", "", "Name of the knowledge base to create (default: exported name)")
+	cmdutil.AddFormatFlag(cmd)
+	cmdutil.AddDryRunFlag(cmd, &opts.DryRun)
+	cmdutil.SetAgentHelp(cmd, cmdutil.AgentHelp{
+		UsedFor:       "Restore or migrate a knowledge base from an export file into this deployment.",
+		RequiredFlags: []string{"<file> (positional; - for stdin)"},
+		Examples:      []string{"weknora admin import my-kb.ndjson --kb target-kb --format json"},
+		Output:        "batch envelope: one item per exported document id; result.knowledge_id is the new document id",
+		Warnings:      []string{"Original files are not restored; documents are recreated from chunk text."},
+	})
+	return cmd
+}
+
+// importBundle is a parsed export file.
+type importBundle struct {
+	knowledgeBase *sdk.KnowledgeBase
+	documents     []*exportDocument
+}
+
+func readImportFile(path string) (*importBundle, error) {
+	var r io.Reader
+	if path == "-" {
+		r = iostreams.IO.In
+	} else {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, cmdutil.Wrapf(cmdutil.CodeLocalFileIO, err, "open %s", path)
+		}
+		defer file.Close()
+		r = file
+	}
+	return parseImport(r)
+}
+
+// parseImport decodes export records. Unknown kinds are skipped so newer
+// exports stay loadable.
+func parseImport(r io.Reader) (*importBundle, error) {
+	bundle := &importBundle{}
+	dec := json.NewDecoder(r)
+	for line := 1; ; line++ {
+		var rec exportRecord
+		if err := dec.Decode(&rec); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, cmdutil.NewError(cmdutil.CodeInputInvalidArgument,
+				fmt.Sprintf("invalid export record %d: %v", line, err))
+		}
+		switch rec.Kind {
+		case exportKindKnowledgeBase:
+			bundle.knowledgeBase = rec.KnowledgeBase
+		case exportKindDocument:
+			if rec.Document != nil {
+				bundle.documents = append(bundle.documents, rec.Document)
+			}
+		}
+	}
+	if bundle.knowledgeBase == nil && len(bundle.documents) == 0 {
+		return nil, cmdutil.NewError(cmdutil.CodeInputInvalidArgument, "export file contains no records")
+	}
+	return bundle, nil
+}
+
+// importKBName is the name used when a new KB is created.
+func importKBName(opts *ImportOptions, bundle *importBundle) string {
+	if opts.Name != "" {
+		return opts.Name
+	}
+	if bundle.knowledgeBase != nil {
+		return bundle.knowledgeBase.Name
+	}
+	return ""
+}
+
+func runImport(ctx context.Context, opts *ImportOptions, fopts *cmdutil.FormatOptions, svc ImportService, bundle *importBundle) error {
+	kbID, err := resolveImportTarget(ctx, opts, svc, bundle)
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]*exportDocument, len(bundle.documents))
+	ids := make([]string, 0, len(bundle.documents))
+	for _, d := range bundle.documents {
+		byID[d.ID] = d
+		ids = append(ids, d.ID)
+	}
+	created := make(map[string]string, len(ids))
+	outcomes, summaryErr := cmdutil.RunBatch(ctx, ids, func(ctx context.Context, id string) error {
+		d := byID[id]
+		content := documentText(d)
+		if content == "" {
+			return cmdutil.NewError(cmdutil.CodeInputInvalidArgument, "document has no text chunks")
+		}
+		k, err := svc.CreateManualKnowledge(ctx, kbID, &sdk.CreateManualKnowledgeRequest{
+			Title:   documentTitle(d),
+			Content: content,
+		})
+		if err != nil {
+			return cmdutil.WrapHTTP(err, "create document %s", documentTitle(d))
+		}
+		created[id] = k.ID
+		return nil
+	})
+	if !fopts.WantsJSON() {
+		fmt.Fprintf(iostreams.IO.Err, "Importing into knowledge base %s\n", kbID)
+	}
+	if err := cmdutil.EmitBatch(outcomes, fopts, iostreams.IO.Out, func(id string) any {
+		return map[string]any{"kb_id": kbID, "knowledge_id": created[id]}
+	}); err != nil {
+		return err
+	}
+	return summaryErr
+}
+
+// resolveImportTarget returns the id of the KB to import into, creating it
+// when --kb is unset.
+func resolveImportTarget(ctx context.Context, opts *ImportOptions, svc ImportService, bundle *importBundle) (string, error) {
+	if opts.KB != "" {
+		return cmdutil.ResolveKBFlag(ctx, svc, opts.KB)
+	}
+	name := importKBName(opts, bundle)
+	if strings.TrimSpace(name) == "" {
+		return "", cmdutil.NewError(cmdutil.CodeInputInvalidArgument,
+			"export has no knowledge_base record: pass --kb or --name")
+	}
+	req := &sdk.KnowledgeBase{Name: name}
+	if src := bundle.knowledgeBase; src != nil {
+		req.Description = src.Description
+		req.ChunkingConfig = src.ChunkingConfig
+	}
+	kb, err := svc.CreateKnowledgeBase(ctx, req)
+	if err != nil {
+		return "", cmdutil.WrapHTTP(err, "create knowledge base")
+	}
+	return kb.ID, nil
+}
+
+// documentText rebuilds a document body from its text chunks in order.
+// Derived chunks (summaries, OCR, captions) are dropped: the server
+// regenerates them during parsing.
+func documentText(d *exportDocument) string {
+	chunks := make([]exportChunk, 0, len(d.Chunks))
+	for _, c := range d.Chunks {
+		if (c.ChunkType == "" || c.ChunkType == "text") && strings.TrimSpace(c.Content) != "" {
+			chunks = append(chunks, c)
+		}
+	}
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].ChunkIndex < chunks[j].ChunkIndex })
+	parts := make([]string, len(chunks))
+	for i, c := range chunks {
+		parts[i] = c.Content
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+func documentTitle(d *exportDocument) string {
+	if d.Title != "" {
+		return d.Title
+	}
+	if d.FileName != "" {
+		return d.FileName
+	}
+	return d.ID
+}
@@ -0,0 +1,91 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+type fakeImportSvc struct {
+	kbs       []sdk.KnowledgeBase
+	createdKB *sdk.KnowledgeBase
+	docs      []*sdk.CreateManualKnowledgeRequest
+	docKB     string
+	failTitle string
+}
+
+func (f *fakeImportSvc) ListKnowledgeBases(context.Context) ([]sdk.KnowledgeBase, error) {
+	return f.kbs, nil
+}
+
+func (f *fakeImportSvc) CreateKnowledgeBase(_ context.Context, kb *sdk.KnowledgeBase) (*sdk.KnowledgeBase, error) {
+	f.createdKB = kb
+	return &sdk.KnowledgeBase{ID: "kb-new", Name: kb.Name}, nil
+}
+
+func (f *fakeImportSvc) CreateManualKnowledge(_ context.Context, kbID string, req *sdk.CreateManualKnowledgeRequest) (*sdk.Knowledge, error) {
+	if req.Title == f.failTitle {
+		return nil, errors.New("HTTP error 500: boom")
+	}
+	f.docKB = kbID
+	f.docs = append(f.docs, req)
+	return &sdk.Knowledge{ID: "k-" + req.Title}, nil
+}
+
+const importFixture = `{"kind":"knowledge_base","knowledge_base":{"id":"kb-old","name":"Docs"}}
+{"kind":"document","document":{"id":"d1","title":"Guide","chunks":[{"chunk_index":0,"chunk_type":"text","content":"hello"}]}}
+{"kind":"document","document":{"id":"d2","title":"Empty","chunks":[]}}
+`
+
+func TestImport_CreatesKBAndDocuments(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	bundle, err := parseImport(strings.NewReader(importFixture))
+	require.NoError(t, err)
+	svc := &fakeImportSvc{}
+
+	err = runImport(context.Background(), &ImportOptions{Name: "Restored"}, &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc, bundle)
+
+	// d2 has no text, so the batch reports one failure.
+	var typed *cmdutil.Error
+	require.ErrorAs(t, err, &typed)
+	assert.Equal(t, cmdutil.CodeOperationFailed, typed.Code)
+
+	require.NotNil(t, svc.createdKB)
+	assert.Equal(t, "Restored", svc.createdKB.Name)
+	assert.Equal(t, "kb-new", svc.docKB)
+	require.Len(t, svc.docs, 1)
+	assert.Equal(t, "Guide", svc.docs[0].Title)
+	assert.Equal(t, "hello", svc.docs[0].Content)
+	assert.Contains(t, out.String(), "OK d1")
+	assert.Contains(t, out.String(), "FAIL d2")
+}
+
+func TestImport_IntoExistingKBByName(t *testing.T) {
+	_, _ = iostreams.SetForTest(t)
+	bundle, err := parseImport(strings.NewReader(importFixture))
+	require.NoError(t, err)
+	bundle.documents = bundle.documents[:1]
+	svc := &fakeImportSvc{kbs: []sdk.KnowledgeBase{{ID: "kb-existing", Name: "target"}}}
+
+	require.NoError(t, runImport(context.Background(), &ImportOptions{KB: "target"}, &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc, bundle))
+	assert.Nil(t, svc.createdKB, "existing KB must not be recreated")
+	assert.Equal(t, "kb-existing", svc.docKB)
+}
+
+func TestParseImport_RejectsGarbage(t *testing.T) {
+	_, err := parseImport(strings.NewReader("not json\n"))
+	var typed *cmdutil.Error
+	require.ErrorAs(t, err, &typed)
+	assert.Equal(t, cmdutil.CodeInputInvalidArgument, typed.Code)
+
+	_, err = parseImport(strings.NewReader(""))
+	require.Error(t, err)
+}
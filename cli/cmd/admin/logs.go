@@ -0,0 +1,196 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/format"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	"github.com/Tencent/WeKnora/cli/internal/output"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+type LogsOptions struct {
+	Follow   bool
+	Interval time.Duration
+	// All includes documents that finished parsing in the initial snapshot.
+	// Without it only pending / processing / failed documents are listed.
+	All bool
+}
+
+// ingestEvent is one ingestion status line: a document entering a parse
+// status. Emitted once per document for the initial snapshot, then once per
+// observed transition under --follow.
+type ingestEvent struct {
+	Time        time.Time `json:"time"`
+	KnowledgeID string    `json:"knowledge_id"`
+	Title       string    `json:"title"`
+	ParseStatus string    `json:"parse_status"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// LogsService is the narrow SDK surface this command depends on.
+// *sdk.Client satisfies it.
+type LogsService interface {
+	knowledgePager
+}
+
+// NewCmdLogs builds `weknora admin logs`.
+func NewCmdLogs(f *cmdutil.Factory) *cobra.Command {
+	opts := &LogsOptions{}
+	cmd := &cobra.Command{
+		Use:   "logs",
+		Short: "Show ingestion status events for a knowledge base",
+		Long: `Lists the ingestion state of documents in the resolved knowledge base,
+one event per document. Completed documents are hidden unless --all is set.
+
+With --follow the command keeps polling and prints an event every time a
+document changes parse status (pending → processing → completed / failed),
+until interrupted. In JSON mode --follow streams NDJSON, one event per line.`,
+		Example: `  weknora admin logs --kb my-kb
+  weknora admin logs --kb my-kb --follow --format text
+  weknora admin logs --kb my-kb --follow --interval 10s | jq 'select(.parse_status=="failed")'`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			fopts, err := cmdutil.CheckFormatFlag(c)
+			if err != nil {
+				return err
+			}
+			fopts.ResolveDefault(iostreams.IO.IsStdoutTTY())
+			if opts.Interval < time.Second {
+				return cmdutil.NewFlagError(fmt.Errorf("--interval must be at least 1s, got %s", opts.Interval))
+			}
+			kbID, err := f.ResolveKB(c)
+			if err != nil {
+				return err
+			}
+			cli, err := f.Client()
+			if err != nil {
+				return err
+			}
+			return runLogs(c.Context(), opts, fopts, cli, kbID)
+		},
+	}
+	cmdutil.AddKBFlag(cmd)
+	cmd.Flags().BoolVarP(&opts.Follow, "follow", "f", false, "Keep polling and print status transitions until interrupted")
+	cmd.Flags().DurationVar(&opts.Interval, "interval", 5*time.Second, "Poll interval for --follow")
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Include completed documents in the initial snapshot")
+	cmdutil.AddFormatFlag(cmd, "time", "knowledge_id", "title", "parse_status", "error")
+	cmdutil.SetAgentHelp(cmd, cmdutil.AgentHelp{
+		UsedFor:  "Inspect or tail document ingestion progress of a KB, e.g. after 'admin reembed' or a bulk upload.",
+		Examples: []string{"weknora admin logs --kb my-kb --format json", "weknora admin logs --kb my-kb --follow --format ndjson"},
+		Output:   "envelope.data is an array of {time, knowledge_id, title, parse_status, error}; --follow streams the same objects as NDJSON",
+		Warnings: []string{"--follow never exits on its own; bound it with a timeout when scripting."},
+	})
+	return cmd
+}
+
+func runLogs(ctx context.Context, opts *LogsOptions, fopts *cmdutil.FormatOptions, svc LogsService, kbID string) error {
+	docs, err := listAllKnowledge(ctx, svc, kbID, sdk.KnowledgeListFilter{})
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]string, len(docs))
+	events := []ingestEvent{}
+	for _, d := range docs {
+		seen[d.ID] = d.ParseStatus
+		if opts.All || d.ParseStatus != "completed" {
+			events = append(events, newIngestEvent(d))
+		}
+	}
+
+	if !opts.Follow {
+		if fopts.WantsJSON() {
+			return fopts.Emit(iostreams.IO.Out, events, &output.Meta{Count: len(events)})
+		}
+		if len(events) == 0 {
+			fmt.Fprintln(iostreams.IO.Out, "(no documents in progress)")
+			return nil
+		}
+		return writeEventsText(events)
+	}
+
+	if err := writeStreamEvents(fopts, events); err != nil {
+		return err
+	}
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// Interrupt is the normal way out of --follow.
+			return nil
+		case <-ticker.C:
+		}
+		docs, err := listAllKnowledge(ctx, svc, kbID, sdk.KnowledgeListFilter{})
+		if err != nil {
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil
+			}
+			return err
+		}
+		if err := writeStreamEvents(fopts, diffIngestStatus(seen, docs)); err != nil {
+			return err
+		}
+	}
+}
+
+// diffIngestStatus returns an event for every document whose parse status
+// differs from seen (new documents included) and records the new status.
+func diffIngestStatus(seen map[string]string, docs []sdk.Knowledge) []ingestEvent {
+	var events []ingestEvent
+	for _, d := range docs {
+		if prev, ok := seen[d.ID]; ok && prev == d.ParseStatus {
+			continue
+		}
+		seen[d.ID] = d.ParseStatus
+		events = append(events, newIngestEvent(d))
+	}
+	return events
+}
+
+func newIngestEvent(d sdk.Knowledge) ingestEvent {
+	ts := d.UpdatedAt
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	title := d.Title
+	if title == "" {
+		title = d.FileName
+	}
+	return ingestEvent{
+		Time:        ts,
+		KnowledgeID: d.ID,
+		Title:       title,
+		ParseStatus: d.ParseStatus,
+		Error:       d.ErrorMessage,
+	}
+}
+
+// writeStreamEvents prints follow-mode events: NDJSON for any JSON mode (an
+// envelope cannot be streamed), plain lines for text.
+func writeStreamEvents(fopts *cmdutil.FormatOptions, events []ingestEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+	if fopts.WantsJSON() {
+		return format.WriteNDJSON(iostreams.IO.Out, events)
+	}
+	return writeEventsText(events)
+}
+
+func writeEventsText(events []ingestEvent) error {
+	for _, e := range events {
+		line := fmt.Sprintf("%s  %-10s  %s  %s", e.Time.Local().Format(time.DateTime), e.ParseStatus, e.KnowledgeID, e.Title)
+		if e.Error != "" {
+			line += "  error: " + e.Error
+		}
+		fmt.Fprintln(iostreams.IO.Out, line)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+func TestLogs_SnapshotHidesCompleted(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeKnowledgePager{docs: []sdk.Knowledge{
+		{ID: "a", Title: "A", ParseStatus: "completed"},
+		{ID: "b", Title: "B", ParseStatus: "failed", ErrorMessage: "bad pdf"},
+	}}
+	require.NoError(t, runLogs(context.Background(), &LogsOptions{Interval: time.Second}, &cmdutil.FormatOptions{Mode: cmdutil.FormatJSON}, svc, "kb1"))
+
+	var env struct {
+		Data []ingestEvent `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &env))
+	require.Len(t, env.Data, 1)
+	assert.Equal(t, "b", env.Data[0].KnowledgeID)
+	assert.Equal(t, "bad pdf", env.Data[0].Error)
+}
+
+func TestDiffIngestStatus(t *testing.T) {
+	seen := map[string]string{"a": "pending", "b": "completed"}
+	events := diffIngestStatus(seen, []sdk.Knowledge{
+		{ID: "a", ParseStatus: "processing"},
+		{ID: "b", ParseStatus: "completed"},
+		{ID: "c", ParseStatus: "pending"},
+	})
+	require.Len(t, events, 2)
+	assert.Equal(t, "a", events[0].KnowledgeID)
+	assert.Equal(t, "processing", events[0].ParseStatus)
+	assert.Equal(t, "c", events[1].KnowledgeID)
+	assert.Equal(t, "processing", seen["a"])
+}
+
+// sequencePager returns snapshots[i] on the i-th listing, repeating the last
+// one once exhausted.
+type sequencePager struct {
+	snapshots [][]sdk.Knowledge
+	calls     int
+}
+
+func (f *sequencePager) ListKnowledgeWithFilter(context.Context, string, int, int, sdk.KnowledgeListFilter) ([]sdk.Knowledge, int64, error) {
+	i := min(f.calls, len(f.snapshots)-1)
+	f.calls++
+	return f.snapshots[i], int64(len(f.snapshots[i])), nil
+}
+
+func TestLogs_FollowStreamsNDJSONUntilCancelled(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &sequencePager{snapshots: [][]sdk.Knowledge{
+		{{ID: "a", ParseStatus: "processing"}},
+		{{ID: "a", ParseStatus: "completed"}},
+	}}
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+
+	require.NoError(t, runLogs(ctx, &LogsOptions{Follow: true, Interval: time.Second}, &cmdutil.FormatOptions{Mode: cmdutil.FormatJSON}, svc, "kb1"))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"parse_status":"processing"`)
+	assert.Contains(t, lines[1], `"parse_status":"completed"`)
+}
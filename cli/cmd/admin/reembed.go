@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+type ReembedOptions struct {
+	// Status restricts the run to documents in one parse_status (e.g.
+	// "failed" to retry only broken ingestions). Empty means every document.
+	Status string
+	DryRun bool
+}
+
+// ReembedService is the narrow SDK surface this command depends on.
+// *sdk.Client satisfies it.
+type ReembedService interface {
+	knowledgePager
+	ReparseKnowledge(ctx context.Context, knowledgeID string) (*sdk.Knowledge, error)
+}
+
+// NewCmdReembed builds `weknora admin reembed`.
+func NewCmdReembed(f *cmdutil.Factory) *cobra.Command {
+	opts := &ReembedOptions{}
+	cmd := &cobra.Command{
+		Use:   "reembed",
+		Short: "Re-run parsing and embedding for every document in a knowledge base",
+		Long: `Queues a reparse for each document in the resolved knowledge base. The
+server drops the document's chunks and vectors and rebuilds them with the
+KB's current embedding model, which is how a KB is migrated after its
+embedding model or vector store changed.
+
+Documents are queued one by one; per-document failures do not stop the run.
+Use 'weknora admin logs --kb <id> --follow' to watch progress.`,
+		Example: `  weknora admin reembed --kb my-kb
+  weknora admin reembed --kb my-kb --status failed     # retry failed ingestions only
+  weknora admin reembed --kb my-kb --dry-run`,
+		Args: cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			fopts, err := cmdutil.CheckFormatFlag(c)
+			if err != nil {
+				return err
+			}
+			fopts.ResolveDefault(iostreams.IO.IsStdoutTTY())
+			kbID, err := f.ResolveKB(c)
+			if err != nil {
+				return err
+			}
+			if handled, err := cmdutil.HandleDryRun(c, opts.DryRun, cmdutil.DryRunPlan{
+				Action: "admin.reembed",
+				Args: map[string]any{
+					"kb_id":  kbID,
+					"status": opts.Status,
+				},
+			}); handled {
+				return err
+			}
+			cli, err := f.Client()
+			if err != nil {
+				return err
+			}
+			return runReembed(c.Context(), opts, fopts, cli, kbID)
+		},
+	}
+	cmdutil.AddKBFlag(cmd)
+	cmd.Flags().StringVar(&opts.Status, "status", "", "Only re-embed documents with this parse status: pending | processing | completed | failed")
+	cmdutil.AddFormatFlag(cmd)
+	cmdutil.AddDryRunFlag(cmd, &opts.DryRun)
+	cmdutil.SetAgentHelp(cmd, cmdutil.AgentHelp{
+		UsedFor:       "Rebuild chunks and embeddings for all documents of a KB, e.g. after switching its embedding model.",
+		RequiredFlags: []string{"--kb (or WEKNORA_KB_ID / project link)"},
+		Examples:      []string{"weknora admin reembed --kb my-kb --format json"},
+		Output:        "batch envelope: one item per document id with ok / error",
+		Warnings:      []string{"Documents are unsearchable while they are being re-processed."},
+	})
+	return cmd
+}
+
+func runReembed(ctx context.Context, opts *ReembedOptions, fopts *cmdutil.FormatOptions, svc ReembedService, kbID string) error {
+	docs, err := listAllKnowledge(ctx, svc, kbID, sdk.KnowledgeListFilter{ParseStatus: opts.Status})
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		if fopts.WantsJSON() {
+			return cmdutil.EmitBatch(nil, fopts, iostreams.IO.Out, nil)
+		}
+		fmt.Fprintln(iostreams.IO.Out, "(no documents to re-embed)")
+		return nil
+	}
+	ids := make([]string, len(docs))
+	for i, d := range docs {
+		ids[i] = d.ID
+	}
+	outcomes, summaryErr := cmdutil.RunBatch(ctx, ids, func(ctx context.Context, id string) error {
+		if _, err := svc.ReparseKnowledge(ctx, id); err != nil {
+			return cmdutil.WrapHTTP(err, "reparse document %s", id)
+		}
+		return nil
+	})
+	if err := cmdutil.EmitBatch(outcomes, fopts, iostreams.IO.Out, nil); err != nil {
+		return err
+	}
+	return summaryErr
+}
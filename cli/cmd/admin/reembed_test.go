@@ -0,0 +1,98 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// fakeKnowledgePager serves docs in pages of pageSize, ignoring the
+// caller's page size so pagination is exercised with small fixtures.
+type fakeKnowledgePager struct {
+	docs     []sdk.Knowledge
+	pageSize int
+	filters  []sdk.KnowledgeListFilter
+}
+
+func (f *fakeKnowledgePager) ListKnowledgeWithFilter(_ context.Context, _ string, page, _ int, filter sdk.KnowledgeListFilter) ([]sdk.Knowledge, int64, error) {
+	f.filters = append(f.filters, filter)
+	size := f.pageSize
+	if size == 0 {
+		size = len(f.docs)
+	}
+	start := (page - 1) * size
+	if start >= len(f.docs) {
+		return nil, int64(len(f.docs)), nil
+	}
+	end := min(start+size, len(f.docs))
+	return f.docs[start:end], int64(len(f.docs)), nil
+}
+
+type fakeReembedSvc struct {
+	fakeKnowledgePager
+	failIDs  map[string]bool
+	reparsed []string
+}
+
+func (f *fakeReembedSvc) ReparseKnowledge(_ context.Context, id string) (*sdk.Knowledge, error) {
+	f.reparsed = append(f.reparsed, id)
+	if f.failIDs[id] {
+		return nil, errors.New("HTTP error 409: busy")
+	}
+	return &sdk.Knowledge{ID: id}, nil
+}
+
+func TestListAllKnowledge_WalksPages(t *testing.T) {
+	svc := &fakeKnowledgePager{
+		docs:     []sdk.Knowledge{{ID: "a"}, {ID: "b"}, {ID: "c"}},
+		pageSize: 2,
+	}
+	docs, err := listAllKnowledge(context.Background(), svc, "kb1", sdk.KnowledgeListFilter{})
+	require.NoError(t, err)
+	assert.Len(t, docs, 3)
+}
+
+func TestReembed_ReparsesEveryDocument(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeReembedSvc{fakeKnowledgePager: fakeKnowledgePager{
+		docs:     []sdk.Knowledge{{ID: "a"}, {ID: "b"}, {ID: "c"}},
+		pageSize: 2,
+	}}
+	opts := &ReembedOptions{Status: "failed"}
+	require.NoError(t, runReembed(context.Background(), opts, &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc, "kb1"))
+
+	assert.Equal(t, []string{"a", "b", "c"}, svc.reparsed)
+	assert.Equal(t, "failed", svc.filters[0].ParseStatus)
+	assert.Equal(t, "OK a\nOK b\nOK c\n", out.String())
+}
+
+func TestReembed_PartialFailureKeepsGoing(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeReembedSvc{
+		fakeKnowledgePager: fakeKnowledgePager{docs: []sdk.Knowledge{{ID: "a"}, {ID: "b"}}},
+		failIDs:            map[string]bool{"a": true},
+	}
+	err := runReembed(context.Background(), &ReembedOptions{}, &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc, "kb1")
+
+	var typed *cmdutil.Error
+	require.ErrorAs(t, err, &typed)
+	assert.Equal(t, cmdutil.CodeOperationFailed, typed.Code)
+	assert.Equal(t, []string{"a", "b"}, svc.reparsed)
+	assert.Contains(t, out.String(), "FAIL a:")
+	assert.Contains(t, out.String(), "OK b")
+}
+
+func TestReembed_NoDocuments(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeReembedSvc{}
+	require.NoError(t, runReembed(context.Background(), &ReembedOptions{}, &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc, "kb1"))
+	assert.Empty(t, svc.reparsed)
+	assert.Contains(t, out.String(), "no documents")
+}
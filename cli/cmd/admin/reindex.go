@@ -0,0 +1,109 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+type ReindexOptions struct {
+	StoreID string
+	DryRun  bool
+}
+
+// ReindexService is the narrow SDK surface this command depends on.
+// *sdk.Client satisfies it.
+type ReindexService interface {
+	ReindexVectorStore(ctx context.Context, storeID string) (*sdk.VectorStoreReindexReport, error)
+}
+
+// NewCmdReindex builds `weknora admin reindex <vector-store-id>`.
+func NewCmdReindex(f *cmdutil.Factory) *cobra.Command {
+	opts := &ReindexOptions{}
+	cmd := &cobra.Command{
+		Use:   "reindex <vector-store-id>",
+		Short: "Rebuild the collections of a vector store",
+		Long: `Rebuilds every collection of a tenant-owned vector store so schema-level
+settings (for example a changed full-text analyzer) take effect. Stored
+vectors are copied as-is; nothing is re-embedded. Use 'weknora admin reembed'
+to regenerate embeddings instead.
+
+Only engines that support rebuilding (currently Milvus) accept the request.`,
+		Example: `  weknora admin reindex 6f1c...   # store id from the vector store settings page`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			fopts, err := cmdutil.CheckFormatFlag(c)
+			if err != nil {
+				return err
+			}
+			fopts.ResolveDefault(iostreams.IO.IsStdoutTTY())
+			opts.StoreID = args[0]
+			if handled, err := cmdutil.HandleDryRun(c, opts.DryRun, cmdutil.DryRunPlan{
+				Action: "admin.reindex",
+				Args:   map[string]any{"store_id": opts.StoreID},
+			}); handled {
+				return err
+			}
+			cli, err := f.Client()
+			if err != nil {
+				return err
+			}
+			return runReindex(c.Context(), opts, fopts, cli)
+		},
+	}
+	cmdutil.AddFormatFlag(cmd, "engine_type", "collections")
+	cmdutil.AddDryRunFlag(cmd, &opts.DryRun)
+	cmdutil.SetAgentHelp(cmd, cmdutil.AgentHelp{
+		UsedFor:       "Rebuild the collections of a vector store after changing immutable index settings.",
+		RequiredFlags: []string{"<vector-store-id> (positional)"},
+		Output:        "envelope.data is {engine_type, collections:[{collection, dimension, rows, error}]}; exit 1 when any collection failed",
+		Warnings:      []string{"Requires tenant admin. Long-running for large collections; writes are not blocked while it runs."},
+	})
+	return cmd
+}
+
+func runReindex(ctx context.Context, opts *ReindexOptions, fopts *cmdutil.FormatOptions, svc ReindexService) error {
+	report, err := svc.ReindexVectorStore(ctx, opts.StoreID)
+	if err != nil {
+		return cmdutil.WrapHTTP(err, "reindex vector store %s", opts.StoreID)
+	}
+
+	var summaryErr error
+	if failed := report.Failed(); failed > 0 {
+		summaryErr = &cmdutil.Error{
+			Code:    cmdutil.CodeOperationFailed,
+			Message: fmt.Sprintf("%d/%d collection(s) failed to reindex", failed, len(report.Collections)),
+			Silent:  true,
+		}
+	}
+
+	if fopts.WantsJSON() {
+		if err := fopts.Emit(iostreams.IO.Out, report, nil); err != nil {
+			return err
+		}
+		return summaryErr
+	}
+	if len(report.Collections) == 0 {
+		fmt.Fprintln(iostreams.IO.Out, "(no collections to reindex)")
+		return nil
+	}
+	tw := tabwriter.NewWriter(iostreams.IO.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COLLECTION\tDIM\tROWS\tRESULT")
+	for _, c := range report.Collections {
+		result := "ok"
+		if c.Error != "" {
+			result = "FAIL: " + c.Error
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\n", c.Collection, c.Dimension, c.Rows, result)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return summaryErr
+}
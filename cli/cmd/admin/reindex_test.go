@@ -0,0 +1,61 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+type fakeReindexSvc struct {
+	report *sdk.VectorStoreReindexReport
+	err    error
+	gotID  string
+}
+
+func (f *fakeReindexSvc) ReindexVectorStore(_ context.Context, id string) (*sdk.VectorStoreReindexReport, error) {
+	f.gotID = id
+	return f.report, f.err
+}
+
+func TestReindex_Success_Text(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeReindexSvc{report: &sdk.VectorStoreReindexReport{
+		EngineType:  "milvus",
+		Collections: []sdk.CollectionReindexResult{{Collection: "weknora_768", Dimension: 768, Rows: 42}},
+	}}
+	require.NoError(t, runReindex(context.Background(), &ReindexOptions{StoreID: "vs-1"}, &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc))
+
+	assert.Equal(t, "vs-1", svc.gotID)
+	assert.Contains(t, out.String(), "weknora_768")
+	assert.Contains(t, out.String(), "ok")
+}
+
+func TestReindex_CollectionFailureEmitsReportThenFails(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeReindexSvc{report: &sdk.VectorStoreReindexReport{
+		EngineType: "milvus",
+		Collections: []sdk.CollectionReindexResult{
+			{Collection: "weknora_768", Dimension: 768, Rows: 42},
+			{Collection: "weknora_1024", Dimension: 1024, Error: "rename failed"},
+		},
+	}}
+	err := runReindex(context.Background(), &ReindexOptions{StoreID: "vs-1"}, &cmdutil.FormatOptions{Mode: cmdutil.FormatJSON}, svc)
+
+	var typed *cmdutil.Error
+	require.ErrorAs(t, err, &typed)
+	assert.Equal(t, cmdutil.CodeOperationFailed, typed.Code)
+	assert.True(t, typed.Silent, "report already on stdout; error envelope must be suppressed")
+
+	var env struct {
+		Data sdk.VectorStoreReindexReport `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &env))
+	assert.Equal(t, 1, env.Data.Failed())
+}
@@ -0,0 +1,103 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// tenantFields enumerates the fields surfaced for `--format json` discovery
+// on tenant commands. api_key is deliberately listed: operators creating a
+// tenant need it to bootstrap the first profile.
+var tenantFields = []string{
+	"id", "name", "description", "api_key", "status", "business",
+	"storage_quota", "storage_used", "created_at", "updated_at",
+}
+
+type TenantCreateOptions struct {
+	Name        string
+	Description string
+	Business    string
+	DryRun      bool
+}
+
+// TenantCreateService is the narrow SDK surface this command depends on.
+// *sdk.Client satisfies it.
+type TenantCreateService interface {
+	CreateTenant(ctx context.Context, tenant *sdk.Tenant) (*sdk.Tenant, error)
+}
+
+// NewCmdTenantCreate builds `weknora admin tenant create <name>`.
+func NewCmdTenantCreate(f *cmdutil.Factory) *cobra.Command {
+	opts := &TenantCreateOptions{}
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Create a new tenant",
+		Example: `  weknora admin tenant create acme
+  weknora admin tenant create acme --description "ACME Corp" --format json`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			fopts, err := cmdutil.CheckFormatFlag(c)
+			if err != nil {
+				return err
+			}
+			fopts.ResolveDefault(iostreams.IO.IsStdoutTTY())
+			opts.Name = args[0]
+			if handled, err := cmdutil.HandleDryRun(c, opts.DryRun, cmdutil.DryRunPlan{
+				Action: "admin.tenant.create",
+				Args: map[string]any{
+					"name":        opts.Name,
+					"description": opts.Description,
+					"business":    opts.Business,
+				},
+			}); handled {
+				return err
+			}
+			cli, err := f.Client()
+			if err != nil {
+				return err
+			}
+			return runTenantCreate(c.Context(), opts, fopts, cli)
+		},
+	}
+	cmd.Flags().StringVar(&opts.Description, "description", "", "Tenant description (optional)")
+	cmd.Flags().StringVar(&opts.Business, "business", "", "Business / department label (optional)")
+	cmdutil.AddFormatFlag(cmd, tenantFields...)
+	cmdutil.AddDryRunFlag(cmd, &opts.DryRun)
+	cmdutil.SetAgentHelp(cmd, cmdutil.AgentHelp{
+		UsedFor:       "Provision a new tenant. The response carries the tenant's api_key, which is the credential for its first profile.",
+		RequiredFlags: []string{"<name> (positional)"},
+		Output:        "envelope.data is the created Tenant object with id, name, api_key, status",
+		Warnings:      []string{"Requires cross-tenant access; the api_key in the output is a secret."},
+	})
+	return cmd
+}
+
+func runTenantCreate(ctx context.Context, opts *TenantCreateOptions, fopts *cmdutil.FormatOptions, svc TenantCreateService) error {
+	if strings.TrimSpace(opts.Name) == "" {
+		return cmdutil.NewError(cmdutil.CodeInputInvalidArgument, "tenant name is required")
+	}
+	created, err := svc.CreateTenant(ctx, &sdk.Tenant{
+		Name:        opts.Name,
+		Description: opts.Description,
+		Business:    opts.Business,
+	})
+	if err != nil {
+		return cmdutil.WrapHTTP(err, "create tenant")
+	}
+
+	if fopts.WantsJSON() {
+		return fopts.Emit(iostreams.IO.Out, created, nil)
+	}
+	fmt.Fprintf(iostreams.IO.Out, "✓ Created tenant %q (id: %d)\n", created.Name, created.ID)
+	if created.APIKey != "" {
+		fmt.Fprintf(iostreams.IO.Out, "  API key: %s\n", created.APIKey)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+type fakeTenantCreateSvc struct {
+	resp *sdk.Tenant
+	err  error
+	got  *sdk.Tenant
+}
+
+func (f *fakeTenantCreateSvc) CreateTenant(_ context.Context, t *sdk.Tenant) (*sdk.Tenant, error) {
+	f.got = t
+	return f.resp, f.err
+}
+
+func TestTenantCreate_Success_Text(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeTenantCreateSvc{resp: &sdk.Tenant{ID: 7, Name: "acme", APIKey: "sk-acme"}}
+	opts := &TenantCreateOptions{Name: "acme", Description: "ACME Corp", Business: "sales"}
+	require.NoError(t, runTenantCreate(context.Background(), opts, &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc))
+
+	require.NotNil(t, svc.got)
+	assert.Equal(t, "acme", svc.got.Name)
+	assert.Equal(t, "ACME Corp", svc.got.Description)
+	assert.Equal(t, "sales", svc.got.Business)
+	assert.Contains(t, out.String(), "✓ Created tenant \"acme\" (id: 7)")
+	assert.Contains(t, out.String(), "sk-acme")
+}
+
+func TestTenantCreate_NameRequired(t *testing.T) {
+	_, _ = iostreams.SetForTest(t)
+	svc := &fakeTenantCreateSvc{}
+	err := runTenantCreate(context.Background(), &TenantCreateOptions{Name: "  "}, &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc)
+
+	var typed *cmdutil.Error
+	require.ErrorAs(t, err, &typed)
+	assert.Equal(t, cmdutil.CodeInputInvalidArgument, typed.Code)
+	assert.Nil(t, svc.got, "service must not be called when name is missing")
+}
+
+func TestTenantCreate_HTTPError(t *testing.T) {
+	_, _ = iostreams.SetForTest(t)
+	svc := &fakeTenantCreateSvc{err: errors.New("HTTP error 403: forbidden")}
+	err := runTenantCreate(context.Background(), &TenantCreateOptions{Name: "acme"}, &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, svc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "create tenant")
+}
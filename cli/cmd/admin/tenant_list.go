@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	"github.com/Tencent/WeKnora/cli/internal/output"
+	"github.com/Tencent/WeKnora/cli/internal/text"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+// TenantListService is the narrow SDK surface this command depends on.
+// *sdk.Client satisfies it.
+type TenantListService interface {
+	ListAllTenants(ctx context.Context) ([]sdk.Tenant, error)
+}
+
+// NewCmdTenantList builds `weknora admin tenant list`.
+func NewCmdTenantList(f *cmdutil.Factory) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List all tenants",
+		Args:  cobra.NoArgs,
+		RunE: func(c *cobra.Command, _ []string) error {
+			fopts, err := cmdutil.CheckFormatFlag(c)
+			if err != nil {
+				return err
+			}
+			fopts.ResolveDefault(iostreams.IO.IsStdoutTTY())
+			cli, err := f.Client()
+			if err != nil {
+				return err
+			}
+			return runTenantList(c.Context(), fopts, cli)
+		},
+	}
+	cmdutil.AddFormatFlag(cmd, tenantFields...)
+	cmdutil.SetAgentHelp(cmd, cmdutil.AgentHelp{
+		UsedFor:  "List every tenant in the deployment, ordered by id.",
+		Examples: []string{"weknora admin tenant list --format json"},
+		Output:   "envelope.data is an array of Tenant objects with id, name, status, storage_used; meta.count is the total",
+		Warnings: []string{"Requires cross-tenant access."},
+	})
+	return cmd
+}
+
+func runTenantList(ctx context.Context, fopts *cmdutil.FormatOptions, svc TenantListService) error {
+	items, err := svc.ListAllTenants(ctx)
+	if err != nil {
+		return cmdutil.WrapHTTP(err, "list tenants")
+	}
+	if items == nil {
+		items = []sdk.Tenant{} // ensure JSON [] not null
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+
+	if fopts.WantsJSON() {
+		return fopts.Emit(iostreams.IO.Out, items, &output.Meta{Count: len(items)})
+	}
+	if len(items) == 0 {
+		fmt.Fprintln(iostreams.IO.Out, "(no tenants)")
+		return nil
+	}
+	tw := tabwriter.NewWriter(iostreams.IO.Out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tSTATUS\tSTORAGE USED")
+	for _, t := range items {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\n", t.ID, text.Truncate(40, t.Name), t.Status, t.StorageUsed)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tencent/WeKnora/cli/internal/cmdutil"
+	"github.com/Tencent/WeKnora/cli/internal/iostreams"
+	sdk "github.com/Tencent/WeKnora/client"
+)
+
+type fakeTenantListSvc struct {
+	items []sdk.Tenant
+	err   error
+}
+
+func (f *fakeTenantListSvc) ListAllTenants(context.Context) ([]sdk.Tenant, error) {
+	return f.items, f.err
+}
+
+func TestTenantList_JSONSortedByID(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	svc := &fakeTenantListSvc{items: []sdk.Tenant{{ID: 3, Name: "c"}, {ID: 1, Name: "a"}}}
+	require.NoError(t, runTenantList(context.Background(), &cmdutil.FormatOptions{Mode: cmdutil.FormatJSON}, svc))
+
+	var env struct {
+		OK   bool         `json:"ok"`
+		Data []sdk.Tenant `json:"data"`
+		Meta struct {
+			Count int `json:"count"`
+		} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &env))
+	assert.True(t, env.OK)
+	require.Len(t, env.Data, 2)
+	assert.Equal(t, uint64(1), env.Data[0].ID)
+	assert.Equal(t, 2, env.Meta.Count)
+}
+
+func TestTenantList_EmptyText(t *testing.T) {
+	out, _ := iostreams.SetForTest(t)
+	require.NoError(t, runTenantList(context.Background(), &cmdutil.FormatOptions{Mode: cmdutil.FormatText}, &fakeTenantListSvc{}))
+	assert.Equal(t, "(no tenants)\n", out.String())
+}
@@ -28,6 +28,7 @@ var dryRunExpectation = map[string]bool{
 	"profile add": true, "profile use": true, "profile remove": true,
 	"auth logout": true, "auth refresh": true,
 	"link": true, "unlink": true,
+	"admin tenant create": true, "admin reembed": true, "admin reindex": true, "admin import": true, "admin apply": true,
 	"api": true, // passthrough: dry-run previews write methods, rejected on GET
 
 	// --- exempt: no state change to preview ---
@@ -39,6 +40,7 @@ var dryRunExpectation = map[string]bool{
 	"session list": false, "session view": false,
 	"agent list": false, "agent view": false, "agent status": false, "agent check": false,
 	"search chunks": false, "search docs": false, "search kb": false, "search sessions": false,
+	"admin tenant list": false, "admin export": false, "admin logs": false, "admin plan": false,
 	"auth list": false, "auth status": false, "auth token": false,
 	"profile list": false,
 	"doctor":       false, "version": false,
@@ -10,6 +10,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	admincmd "github.com/Tencent/WeKnora/cli/cmd/admin"
 	agentcmd "github.com/Tencent/WeKnora/cli/cmd/agent"
 	apicmd "github.com/Tencent/WeKnora/cli/cmd/api"
 	"github.com/Tencent/WeKnora/cli/cmd/auth"
@@ -198,6 +199,7 @@ a curated read-only MCP tool surface for AI agents.`,
 	cmd.AddCommand(agentcmd.NewCmd(f))
 	cmd.AddCommand(chunkcmd.NewCmdChunk(f))
 	cmd.AddCommand(mcpcmd.NewCmd(f))
+	cmd.AddCommand(admincmd.NewCmd(f))
 	installUnknownSubcommandGuard(cmd)
 	return cmd
 }
@@ -44,6 +44,7 @@ type AgentConfig struct {
 	Thinking                    *bool    `json:"thinking"`
 	MaxIterations               int      `json:"max_iterations"`
 	LLMCallTimeout              int      `json:"llm_call_timeout,omitempty"`
+	RunTimeout                  int      `json:"run_timeout,omitempty"`
 	AllowedTools                []string `json:"allowed_tools"`
 	MCPSelectionMode            string   `json:"mcp_selection_mode"`
 	MCPServices                 []string `json:"mcp_services"`
@@ -52,6 +53,8 @@ type AgentConfig struct {
 	KBSelectionMode             string   `json:"kb_selection_mode"`
 	KnowledgeBases              []string `json:"knowledge_bases"`
 	RetrieveKBOnlyWhenMentioned bool     `json:"retrieve_kb_only_when_mentioned"`
+	KBAutoRoute                 bool     `json:"kb_auto_route,omitempty"`
+	KBRouteMaxKBs               int      `json:"kb_route_max_kbs,omitempty"`
 	RetainRetrievalHistory      bool     `json:"retain_retrieval_history"`
 	ImageUploadEnabled          bool     `json:"image_upload_enabled"`
 	VLMModelID                  string   `json:"vlm_model_id"`
@@ -60,6 +63,8 @@ type AgentConfig struct {
 	ImageStorageProvider        string   `json:"image_storage_provider"`
 	SupportedFileTypes          []string `json:"supported_file_types"`
 	DataAnalysisEnabled         bool     `json:"data_analysis_enabled"`
+	Text2SQLEnabled             bool     `json:"text2sql_enabled,omitempty"`
+	SQLConnectionIDs            []string `json:"sql_connection_ids,omitempty"`
 	FAQPriorityEnabled          bool     `json:"faq_priority_enabled"`
 	FAQDirectAnswerThreshold    float64  `json:"faq_direct_answer_threshold"`
 	FAQScoreBoost               float64  `json:"faq_score_boost"`
@@ -76,6 +81,9 @@ type AgentConfig struct {
 	RerankTopK                  int      `json:"rerank_top_k"`
 	RerankThreshold             float64  `json:"rerank_threshold"`
 	EnableQueryExpansion        bool     `json:"enable_query_expansion"`
+	MultiQueryEnabled           bool     `json:"multi_query_enabled,omitempty"`
+	MultiQueryCount             int      `json:"multi_query_count,omitempty"`
+	MultiQueryStrategies        []string `json:"multi_query_strategies,omitempty"`
 	EnableRewrite               bool     `json:"enable_rewrite"`
 	RewritePromptSystem         string   `json:"rewrite_prompt_system"`
 	RewritePromptUser           string   `json:"rewrite_prompt_user"`
@@ -83,7 +91,22 @@ type AgentConfig struct {
 	FallbackStrategy            string   `json:"fallback_strategy"`
 	FallbackResponse            string   `json:"fallback_response"`
 	FallbackPrompt              string   `json:"fallback_prompt"`
+	AnswerVerificationEnabled   bool     `json:"answer_verification_enabled,omitempty"`
+	AnswerVerificationAction    string   `json:"answer_verification_action,omitempty"`
+	FollowUpQuestionsEnabled    bool     `json:"follow_up_questions_enabled,omitempty"`
 	SuggestedPrompts            []string `json:"suggested_prompts,omitempty"`
+
+	// Input token budget of the answering model and its split (normal mode)
+	ContextTokenBudget  int                  `json:"context_token_budget,omitempty"`
+	ContextBudgetRatios *ContextBudgetRatios `json:"context_budget_ratios,omitempty"`
+}
+
+// ContextBudgetRatios splits an agent's context token budget between memory,
+// retrieved chunks and history.
+type ContextBudgetRatios struct {
+	Memory  float64 `json:"memory"`
+	Chunks  float64 `json:"chunks"`
+	History float64 `json:"history"`
 }
 
 // CreateAgentRequest represents the request to create an agent.
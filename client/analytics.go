@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// AnalyticsQuery filters query analytics. Dates are UTC days formatted as
+// YYYY-MM-DD; the range defaults to the last 30 days. Limit bounds the
+// length of ranked lists, 20 by default and at most 100.
+type AnalyticsQuery struct {
+	StartDate       string
+	EndDate         string
+	KnowledgeBaseID string
+	Limit           int
+}
+
+func (q *AnalyticsQuery) values() url.Values {
+	query := url.Values{}
+	if q == nil {
+		return query
+	}
+	if q.StartDate != "" {
+		query.Set("start_date", q.StartDate)
+	}
+	if q.EndDate != "" {
+		query.Set("end_date", q.EndDate)
+	}
+	if q.KnowledgeBaseID != "" {
+		query.Set("knowledge_base_id", q.KnowledgeBaseID)
+	}
+	if q.Limit > 0 {
+		query.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return query
+}
+
+// QueryDayCounts counts the queries of one day
+type QueryDayCounts struct {
+	Day          string `json:"day"`
+	Queries      int64  `json:"queries"`
+	Searched     int64  `json:"searched"`
+	ZeroResults  int64  `json:"zero_results"`
+	AvgLatencyMs int64  `json:"avg_latency_ms"`
+}
+
+// LatencyPercentile is the answer latency at a percentile
+type LatencyPercentile struct {
+	Percentile int   `json:"percentile"`
+	LatencyMs  int64 `json:"latency_ms"`
+}
+
+// QueryAnalyticsOverview aggregates the queries of the tenant over a date range
+type QueryAnalyticsOverview struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Queries   int64  `json:"queries"`
+	// Searched counts the queries that searched knowledge
+	Searched    int64 `json:"searched"`
+	ZeroResults int64 `json:"zero_results"`
+	// ZeroResultRate is ZeroResults over Searched
+	ZeroResultRate float64              `json:"zero_result_rate"`
+	AvgLatencyMs   int64                `json:"avg_latency_ms"`
+	Latency        []*LatencyPercentile `json:"latency_percentiles"`
+	Days           []*QueryDayCounts    `json:"days"`
+}
+
+// QueryStat counts the times a query was asked
+type QueryStat struct {
+	Query        string `json:"query"`
+	Count        int64  `json:"count"`
+	ZeroResults  int64  `json:"zero_results"`
+	AvgLatencyMs int64  `json:"avg_latency_ms"`
+	LastDay      string `json:"last_day"`
+}
+
+// DocumentStat counts the times answers cited and retrieved a document
+type DocumentStat struct {
+	KnowledgeID     string `json:"knowledge_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	KnowledgeTitle  string `json:"knowledge_title"`
+	Citations       int64  `json:"citations"`
+	Retrievals      int64  `json:"retrievals"`
+}
+
+// KnowledgeBaseDayCounts counts the queries that searched a knowledge base on one day
+type KnowledgeBaseDayCounts struct {
+	Day         string `json:"day"`
+	Queries     int64  `json:"queries"`
+	ZeroResults int64  `json:"zero_results"`
+}
+
+// KnowledgeBaseUsage counts the queries that searched a knowledge base
+type KnowledgeBaseUsage struct {
+	KnowledgeBaseID string                    `json:"knowledge_base_id"`
+	Name            string                    `json:"name"`
+	Queries         int64                     `json:"queries"`
+	ZeroResults     int64                     `json:"zero_results"`
+	Days            []*KnowledgeBaseDayCounts `json:"days"`
+}
+
+// GetQueryAnalyticsOverview returns the query volume, zero-result rate and
+// latency percentiles of the tenant
+func (c *Client) GetQueryAnalyticsOverview(
+	ctx context.Context, query *AnalyticsQuery,
+) (*QueryAnalyticsOverview, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/analytics/overview", nil, query.values())
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                    `json:"success"`
+		Data    *QueryAnalyticsOverview `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetTopQueries returns the most asked queries
+func (c *Client) GetTopQueries(ctx context.Context, query *AnalyticsQuery) ([]*QueryStat, error) {
+	return c.getQueryStats(ctx, "/api/v1/analytics/top-queries", query)
+}
+
+// GetZeroResultQueries returns the queries that most often retrieved nothing
+func (c *Client) GetZeroResultQueries(ctx context.Context, query *AnalyticsQuery) ([]*QueryStat, error) {
+	return c.getQueryStats(ctx, "/api/v1/analytics/zero-result-queries", query)
+}
+
+func (c *Client) getQueryStats(ctx context.Context, path string, query *AnalyticsQuery) ([]*QueryStat, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, query.values())
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool         `json:"success"`
+		Data    []*QueryStat `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetTopDocuments returns the documents answers cited most
+func (c *Client) GetTopDocuments(ctx context.Context, query *AnalyticsQuery) ([]*DocumentStat, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/analytics/documents", nil, query.values())
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool            `json:"success"`
+		Data    []*DocumentStat `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetKnowledgeBaseUsage returns the queries per knowledge base and day
+func (c *Client) GetKnowledgeBaseUsage(ctx context.Context, query *AnalyticsQuery) ([]*KnowledgeBaseUsage, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/analytics/knowledge-bases", nil, query.values())
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                  `json:"success"`
+		Data    []*KnowledgeBaseUsage `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
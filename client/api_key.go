@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// API key scopes
+const (
+	APIKeyScopeAll    = "all"
+	APIKeyScopeRead   = "read"
+	APIKeyScopeChat   = "chat"
+	APIKeyScopeIngest = "ingest"
+)
+
+// APIKey is a managed API key of a tenant. Its secret is only returned when
+// the key is created or rotated.
+type APIKey struct {
+	ID       string `json:"id"`
+	TenantID uint64 `json:"tenant_id"`
+	Name     string `json:"name"`
+	// KeyPrefix is the start of the secret, shown to tell keys apart
+	KeyPrefix string `json:"key_prefix"`
+	// Role is the tenant role requests made with the key act with
+	Role   string   `json:"role"`
+	Scopes []string `json:"scopes"`
+	// KnowledgeBaseIDs restricts the key to these knowledge bases; empty
+	// means no restriction
+	KnowledgeBaseIDs []string   `json:"knowledge_base_ids"`
+	ExpiresAt        *time.Time `json:"expires_at"`
+	LastUsedAt       *time.Time `json:"last_used_at"`
+	CreatedBy        string     `json:"created_by"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	// Secret is set only in the responses of CreateAPIKey and RotateAPIKey
+	Secret string `json:"secret,omitempty"`
+}
+
+// APIKeyRequest creates an API key or replaces its settings.
+type APIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// Role defaults to viewer for read/chat keys and admin otherwise
+	Role             string     `json:"role,omitempty"`
+	KnowledgeBaseIDs []string   `json:"knowledge_base_ids,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+}
+
+// APIKeyResponse wraps a single API key response.
+type APIKeyResponse struct {
+	Success bool    `json:"success"`
+	Data    *APIKey `json:"data"`
+}
+
+// APIKeyListResponse wraps the API key list response.
+type APIKeyListResponse struct {
+	Success bool      `json:"success"`
+	Data    []*APIKey `json:"data"`
+}
+
+// ListAPIKeys lists the API keys of the current tenant.
+func (c *Client) ListAPIKeys(ctx context.Context) ([]*APIKey, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/api-keys", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response APIKeyListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// CreateAPIKey creates an API key. The returned key carries its secret.
+func (c *Client) CreateAPIKey(ctx context.Context, req *APIKeyRequest) (*APIKey, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/api-keys", req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response APIKeyResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// UpdateAPIKey replaces the settings of an API key. The secret is unchanged.
+func (c *Client) UpdateAPIKey(ctx context.Context, id string, req *APIKeyRequest) (*APIKey, error) {
+	path := fmt.Sprintf("/api/v1/api-keys/%s", id)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response APIKeyResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// RotateAPIKey replaces the secret of an API key; the old secret stops
+// working at once. The returned key carries the new secret.
+func (c *Client) RotateAPIKey(ctx context.Context, id string) (*APIKey, error) {
+	path := fmt.Sprintf("/api/v1/api-keys/%s/rotate", id)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response APIKeyResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// RevokeAPIKey revokes an API key.
+func (c *Client) RevokeAPIKey(ctx context.Context, id string) error {
+	path := fmt.Sprintf("/api/v1/api-keys/%s", id)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool `json:"success"`
+	}
+	return parseResponse(resp, &response)
+}
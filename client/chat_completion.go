@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// ChatCompletionMessage is one message of a chat completion request
+type ChatCompletionMessage struct {
+	Role    string `json:"role"` // system, user or assistant
+	Content string `json:"content"`
+}
+
+// ChatCompletionJSONSchema is the schema of the json_schema response format
+type ChatCompletionJSONSchema struct {
+	Name   string          `json:"name,omitempty"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+// ChatCompletionResponseFormat selects plain text ("text"), any JSON object
+// ("json_object") or JSON matching a schema ("json_schema"). JSON output is
+// validated by the server, which asks the model to repair an invalid answer
+// up to MaxRepairs times.
+type ChatCompletionResponseFormat struct {
+	Type       string                    `json:"type"`
+	JSONSchema *ChatCompletionJSONSchema `json:"json_schema,omitempty"`
+	MaxRepairs *int                      `json:"max_repairs,omitempty"`
+}
+
+// ChatCompletionRequest is a direct chat model call, without retrieval or
+// session history
+type ChatCompletionRequest struct {
+	ModelID        string                        `json:"model_id"`
+	Messages       []ChatCompletionMessage       `json:"messages"`
+	Temperature    *float64                      `json:"temperature,omitempty"`
+	TopP           *float64                      `json:"top_p,omitempty"`
+	MaxTokens      int                           `json:"max_tokens,omitempty"`
+	ResponseFormat *ChatCompletionResponseFormat `json:"response_format,omitempty"`
+}
+
+// ChatCompletionUsage is the token usage of all model calls of a completion
+type ChatCompletionUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// ChatCompletionResponse is the answer of a chat completion. Parsed holds
+// the validated JSON when a JSON response format was requested.
+type ChatCompletionResponse struct {
+	ModelID      string              `json:"model_id"`
+	Content      string              `json:"content"`
+	Parsed       json.RawMessage     `json:"parsed,omitempty"`
+	FinishReason string              `json:"finish_reason,omitempty"`
+	Usage        ChatCompletionUsage `json:"usage"`
+	Attempts     int                 `json:"attempts"`
+}
+
+// ChatCompletion calls a chat model directly
+func (c *Client) ChatCompletion(ctx context.Context, request *ChatCompletionRequest) (*ChatCompletionResponse, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/chat/completions", request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Success bool                    `json:"success"`
+		Data    *ChatCompletionResponse `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
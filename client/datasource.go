@@ -0,0 +1,91 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The DataSource related interfaces manage external connectors (Notion,
+// Feishu, Confluence, ...) that sync documents into a knowledge base
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DataSourceConfig is the connector configuration. Credentials are
+// write-only: the server accepts them on create and never returns them;
+// later changes go through the credentials subresource.
+type DataSourceConfig struct {
+	Type        string         `json:"type,omitempty"`
+	Credentials map[string]any `json:"credentials,omitempty"`
+	ResourceIDs []string       `json:"resource_ids,omitempty"`
+	Settings    map[string]any `json:"settings,omitempty"`
+}
+
+// DataSource is a connector bound to a knowledge base
+type DataSource struct {
+	ID               string            `json:"id,omitempty"`
+	TenantID         uint64            `json:"tenant_id,omitempty"`
+	KnowledgeBaseID  string            `json:"knowledge_base_id"`
+	Name             string            `json:"name"`
+	Type             string            `json:"type"`
+	Config           *DataSourceConfig `json:"config,omitempty"`
+	SyncSchedule     string            `json:"sync_schedule"`
+	SyncMode         string            `json:"sync_mode,omitempty"`
+	Status           string            `json:"status,omitempty"`
+	ConflictStrategy string            `json:"conflict_strategy,omitempty"`
+	SyncDeletions    bool              `json:"sync_deletions"`
+	LastSyncAt       *time.Time        `json:"last_sync_at,omitempty"`
+	ErrorMessage     string            `json:"error_message,omitempty"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+}
+
+// ListDataSources lists the connectors of a knowledge base
+func (c *Client) ListDataSources(ctx context.Context, knowledgeBaseID string) ([]DataSource, error) {
+	query := url.Values{}
+	query.Set("kb_id", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/datasource", nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response []DataSource
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// CreateDataSource creates a connector. The response body is the data
+// source itself, not a {success, data} envelope.
+func (c *Client) CreateDataSource(ctx context.Context, dataSource *DataSource) (*DataSource, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/datasource", dataSource, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response DataSource
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UpdateDataSource replaces the mutable fields of a connector. The bound
+// knowledge base and stored credentials are kept by the server.
+func (c *Client) UpdateDataSource(ctx context.Context, dataSource *DataSource) (*DataSource, error) {
+	if dataSource.ID == "" {
+		return nil, fmt.Errorf("data source ID cannot be empty")
+	}
+	path := fmt.Sprintf("/api/v1/datasource/%s", dataSource.ID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, dataSource, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response DataSource
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
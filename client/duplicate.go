@@ -0,0 +1,133 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The Duplicate related interfaces are used to find exact and near duplicate
+// content in a knowledge base and remove the redundant copies
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// DuplicateMember is one copy in a duplicate group: a chunk, or a whole
+// document when ChunkID is empty
+type DuplicateMember struct {
+	KnowledgeID    string  `json:"knowledge_id"`
+	KnowledgeTitle string  `json:"knowledge_title"`
+	ChunkID        string  `json:"chunk_id,omitempty"`
+	ChunkIndex     int     `json:"chunk_index,omitempty"`
+	Preview        string  `json:"preview,omitempty"`
+	Similarity     float64 `json:"similarity"` // Estimated similarity to the kept copy
+}
+
+// DuplicateGroup is a kept copy and the redundant copies duplicating it
+type DuplicateGroup struct {
+	Kind       string            `json:"kind"` // exact, near
+	Keep       DuplicateMember   `json:"keep"`
+	Duplicates []DuplicateMember `json:"duplicates"`
+}
+
+// DuplicateReport is the latest duplicate analysis of a knowledge base
+type DuplicateReport struct {
+	KnowledgeBaseID        string           `json:"knowledge_base_id"`
+	Status                 string           `json:"status"` // running, completed, failed
+	Threshold              float64          `json:"threshold"`
+	ChunkCount             int              `json:"chunk_count"`
+	DocumentCount          int              `json:"document_count"`
+	Truncated              bool             `json:"truncated"`
+	DocumentGroups         []DuplicateGroup `json:"document_groups"`
+	ChunkGroups            []DuplicateGroup `json:"chunk_groups"`
+	RedundantDocumentCount int              `json:"redundant_document_count"`
+	RedundantChunkCount    int              `json:"redundant_chunk_count"`
+	ErrorMessage           string           `json:"error_message"`
+	CreatedAt              time.Time        `json:"created_at"`
+	CompletedAt            *time.Time       `json:"completed_at"`
+	RemovedAt              *time.Time       `json:"removed_at"`
+}
+
+// RemoveDuplicatesRequest selects how the redundant copies are removed
+type RemoveDuplicatesRequest struct {
+	Action        string `json:"action,omitempty"` // disable (default), delete
+	SkipDocuments bool   `json:"skip_documents,omitempty"`
+	SkipChunks    bool   `json:"skip_chunks,omitempty"`
+}
+
+// RemoveDuplicatesResult counts what removing the duplicates changed
+type RemoveDuplicatesResult struct {
+	Action           string `json:"action"`
+	DocumentsDeleted int    `json:"documents_deleted"`
+	ChunksDisabled   int    `json:"chunks_disabled"`
+	ChunksDeleted    int    `json:"chunks_deleted"`
+	Skipped          int    `json:"skipped"`
+}
+
+// DuplicateReportResponse represents a duplicate report response
+type DuplicateReportResponse struct {
+	Success bool            `json:"success"`
+	Data    DuplicateReport `json:"data"`
+}
+
+// RemoveDuplicatesResponse represents a duplicate removal response
+type RemoveDuplicatesResponse struct {
+	Success bool                   `json:"success"`
+	Data    RemoveDuplicatesResult `json:"data"`
+}
+
+// AnalyzeDuplicates starts a duplicate analysis of a knowledge base. A zero
+// threshold uses the server default
+func (c *Client) AnalyzeDuplicates(
+	ctx context.Context, knowledgeBaseID string, threshold float64,
+) (*DuplicateReport, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/duplicates/analyze", knowledgeBaseID)
+	body := map[string]float64{}
+	if threshold > 0 {
+		body["threshold"] = threshold
+	}
+	resp, err := c.doRequest(ctx, http.MethodPost, path, body, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response DuplicateReportResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// GetDuplicateReport gets the latest duplicate report of a knowledge base
+func (c *Client) GetDuplicateReport(ctx context.Context, knowledgeBaseID string) (*DuplicateReport, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/duplicates", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response DuplicateReportResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// RemoveDuplicates disables or deletes the redundant copies of the latest
+// duplicate report
+func (c *Client) RemoveDuplicates(
+	ctx context.Context, knowledgeBaseID string, request *RemoveDuplicatesRequest,
+) (*RemoveDuplicatesResult, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/duplicates/remove", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response RemoveDuplicatesResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
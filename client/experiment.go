@@ -0,0 +1,230 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ExperimentConfig is the pipeline configuration an experiment answers
+// with. Unset fields keep the server's conversation defaults; at least one
+// field must differ from production.
+type ExperimentConfig struct {
+	EmbeddingTopK    int      `json:"embedding_top_k,omitempty"`
+	VectorThreshold  *float64 `json:"vector_threshold,omitempty"`
+	KeywordThreshold *float64 `json:"keyword_threshold,omitempty"`
+	RerankModelID    string   `json:"rerank_model_id,omitempty"`
+	RerankTopK       int      `json:"rerank_top_k,omitempty"`
+	RerankThreshold  *float64 `json:"rerank_threshold,omitempty"`
+	ChatModelID      string   `json:"chat_model_id,omitempty"`
+	// JudgeModelID is the model comparing the two answers; defaults to the
+	// chat model the shadow answers with
+	JudgeModelID string `json:"judge_model_id,omitempty"`
+}
+
+// ExperimentReport summarises the verdicts of an experiment's samples.
+// Rates and averages only cover judged samples.
+type ExperimentReport struct {
+	Samples            int64   `json:"samples"`
+	Judged             int64   `json:"judged"`
+	ShadowWins         int64   `json:"shadow_wins"`
+	ProductionWins     int64   `json:"production_wins"`
+	Ties               int64   `json:"ties"`
+	Failed             int64   `json:"failed"`
+	ShadowWinRate      float64 `json:"shadow_win_rate"`
+	ProductionWinRate  float64 `json:"production_win_rate"`
+	TieRate            float64 `json:"tie_rate"`
+	AvgShadowLatencyMs int64   `json:"avg_shadow_latency_ms"`
+	AvgSourceOverlap   float64 `json:"avg_source_overlap"`
+}
+
+// Experiment answers a sampled share of production questions again with an
+// alternative pipeline configuration and has a judge model compare the
+// answers. Users only ever see the production answer.
+type Experiment struct {
+	ID               string            `json:"id"`
+	TenantID         uint64            `json:"tenant_id"`
+	Name             string            `json:"name"`
+	Description      string            `json:"description"`
+	KnowledgeBaseIDs []string          `json:"knowledge_base_ids"`
+	SamplePercent    float64           `json:"sample_percent"`
+	MaxSamples       int               `json:"max_samples"`
+	Sampled          int               `json:"sampled"`
+	Status           string            `json:"status"` // running, paused, completed
+	Config           ExperimentConfig  `json:"config"`
+	CreatedBy        string            `json:"created_by"`
+	CreatedAt        time.Time         `json:"created_at"`
+	UpdatedAt        time.Time         `json:"updated_at"`
+	Report           *ExperimentReport `json:"report,omitempty"`
+}
+
+// ExperimentRequest creates an experiment. An empty KnowledgeBaseIDs
+// samples answers from every knowledge base; MaxSamples defaults to 500.
+type ExperimentRequest struct {
+	Name             string           `json:"name"`
+	Description      string           `json:"description,omitempty"`
+	KnowledgeBaseIDs []string         `json:"knowledge_base_ids,omitempty"`
+	SamplePercent    float64          `json:"sample_percent"`
+	MaxSamples       int              `json:"max_samples,omitempty"`
+	Config           ExperimentConfig `json:"config"`
+}
+
+// ExperimentUpdateRequest changes the fields that are set. Status is
+// "running" or "paused"; the configuration cannot be changed.
+type ExperimentUpdateRequest struct {
+	Name          *string  `json:"name,omitempty"`
+	Description   *string  `json:"description,omitempty"`
+	SamplePercent *float64 `json:"sample_percent,omitempty"`
+	MaxSamples    *int     `json:"max_samples,omitempty"`
+	Status        *string  `json:"status,omitempty"`
+}
+
+// ExperimentSource is one chunk an answer was based on.
+type ExperimentSource struct {
+	ChunkID     string  `json:"chunk_id"`
+	KnowledgeID string  `json:"knowledge_id"`
+	Title       string  `json:"title"`
+	Score       float64 `json:"score"`
+}
+
+// ExperimentSample is one production answer answered again in the shadow.
+// Winner is production, shadow, tie or, when the sample failed, empty.
+type ExperimentSample struct {
+	ID                string             `json:"id"`
+	ExperimentID      string             `json:"experiment_id"`
+	TenantID          uint64             `json:"tenant_id"`
+	SessionID         string             `json:"session_id"`
+	MessageID         string             `json:"message_id"`
+	Question          string             `json:"question"`
+	ProductionAnswer  string             `json:"production_answer"`
+	ShadowAnswer      string             `json:"shadow_answer"`
+	ProductionSources []ExperimentSource `json:"production_sources"`
+	ShadowSources     []ExperimentSource `json:"shadow_sources"`
+	SourceOverlap     float64            `json:"source_overlap"`
+	ShadowLatencyMs   int64              `json:"shadow_latency_ms"`
+	Winner            string             `json:"winner"`
+	JudgeReason       string             `json:"judge_reason"`
+	ErrMsg            string             `json:"err_msg"`
+	CreatedAt         time.Time          `json:"created_at"`
+}
+
+// ExperimentSamplesPage contains paginated experiment samples.
+type ExperimentSamplesPage struct {
+	Total    int64               `json:"total"`
+	Page     int                 `json:"page"`
+	PageSize int                 `json:"page_size"`
+	Data     []*ExperimentSample `json:"data"`
+}
+
+// CreateExperiment creates a running shadow experiment.
+func (c *Client) CreateExperiment(ctx context.Context, req *ExperimentRequest) (*Experiment, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/experiments", req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseExperiment(resp)
+}
+
+// ListExperiments lists the experiments of the tenant with their reports,
+// newest first.
+func (c *Client) ListExperiments(ctx context.Context) ([]*Experiment, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/experiments", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool          `json:"success"`
+		Data    []*Experiment `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetExperiment gets an experiment with its report.
+func (c *Client) GetExperiment(ctx context.Context, id string) (*Experiment, error) {
+	path := fmt.Sprintf("/api/v1/experiments/%s", id)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseExperiment(resp)
+}
+
+// UpdateExperiment renames, resizes, pauses or resumes an experiment.
+func (c *Client) UpdateExperiment(
+	ctx context.Context, id string, req *ExperimentUpdateRequest,
+) (*Experiment, error) {
+	path := fmt.Sprintf("/api/v1/experiments/%s", id)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseExperiment(resp)
+}
+
+// DeleteExperiment deletes an experiment with its samples.
+func (c *Client) DeleteExperiment(ctx context.Context, id string) error {
+	path := fmt.Sprintf("/api/v1/experiments/%s", id)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool `json:"success"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// ListExperimentSamples returns a page of an experiment's samples, newest
+// first. winner filters them by verdict: production, shadow, tie, or failed
+// for samples without one; empty lists all.
+func (c *Client) ListExperimentSamples(
+	ctx context.Context, id string, winner string, page, pageSize int,
+) (*ExperimentSamplesPage, error) {
+	path := fmt.Sprintf("/api/v1/experiments/%s/samples", id)
+	query := url.Values{}
+	if winner != "" {
+		query.Add("winner", winner)
+	}
+	if page > 0 {
+		query.Add("page", strconv.Itoa(page))
+	}
+	if pageSize > 0 {
+		query.Add("page_size", strconv.Itoa(pageSize))
+	}
+
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                   `json:"success"`
+		Data    *ExperimentSamplesPage `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	if response.Data == nil {
+		return &ExperimentSamplesPage{}, nil
+	}
+	return response.Data, nil
+}
+
+func parseExperiment(resp *http.Response) (*Experiment, error) {
+	var response struct {
+		Success bool        `json:"success"`
+		Data    *Experiment `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
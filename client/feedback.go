@@ -0,0 +1,240 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Feedback ratings
+const (
+	FeedbackRatingUp   = "up"
+	FeedbackRatingDown = "down"
+)
+
+// Feedback export formats
+const (
+	// FeedbackExportRaw exports one MessageFeedback per line
+	FeedbackExportRaw = "feedback"
+	// FeedbackExportEval exports up-voted answers as evaluation dataset items
+	FeedbackExportEval = "eval"
+	// FeedbackExportRerank exports query, positives and negatives for reranker training
+	FeedbackExportRerank = "rerank"
+)
+
+// FeedbackSource is a snapshot of a chunk retrieved for an answer that
+// received feedback.
+type FeedbackSource struct {
+	Position        int     `json:"position"`
+	ChunkID         string  `json:"chunk_id"`
+	KnowledgeID     string  `json:"knowledge_id"`
+	KnowledgeBaseID string  `json:"knowledge_base_id"`
+	KnowledgeTitle  string  `json:"knowledge_title"`
+	Content         string  `json:"content"`
+	Score           float64 `json:"score"`
+	Cited           bool    `json:"cited"`
+	Wrong           bool    `json:"wrong"`
+}
+
+// MessageFeedback is a user's feedback on an assistant message.
+type MessageFeedback struct {
+	ID           string            `json:"id"`
+	TenantID     uint64            `json:"tenant_id"`
+	SessionID    string            `json:"session_id"`
+	MessageID    string            `json:"message_id"`
+	UserID       string            `json:"user_id"`
+	Rating       string            `json:"rating"`
+	Comment      string            `json:"comment"`
+	WrongSources int               `json:"wrong_sources"`
+	Day          string            `json:"day"`
+	Question     string            `json:"question"`
+	Answer       string            `json:"answer"`
+	Sources      []*FeedbackSource `json:"sources,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// MessageFeedbackRequest rates an answer, marks the retrieved chunks that
+// were wrong sources, or both.
+type MessageFeedbackRequest struct {
+	// Rating is "up", "down" or empty when only marking wrong sources
+	Rating        string   `json:"rating,omitempty"`
+	Comment       string   `json:"comment,omitempty"`
+	WrongChunkIDs []string `json:"wrong_chunk_ids,omitempty"`
+}
+
+// FeedbackQuery filters feedback stats and exports. Dates are UTC days
+// formatted as YYYY-MM-DD; the range defaults to the last 30 days.
+type FeedbackQuery struct {
+	StartDate       string
+	EndDate         string
+	KnowledgeBaseID string
+	Rating          string
+}
+
+func (q *FeedbackQuery) values() url.Values {
+	query := url.Values{}
+	if q == nil {
+		return query
+	}
+	if q.StartDate != "" {
+		query.Set("start_date", q.StartDate)
+	}
+	if q.EndDate != "" {
+		query.Set("end_date", q.EndDate)
+	}
+	if q.KnowledgeBaseID != "" {
+		query.Set("knowledge_base_id", q.KnowledgeBaseID)
+	}
+	if q.Rating != "" {
+		query.Set("rating", q.Rating)
+	}
+	return query
+}
+
+// FeedbackCounts counts feedback by kind
+type FeedbackCounts struct {
+	Total       int64 `json:"total"`
+	Up          int64 `json:"up"`
+	Down        int64 `json:"down"`
+	WrongSource int64 `json:"wrong_source"`
+}
+
+// FeedbackDayCounts is the feedback given on one day
+type FeedbackDayCounts struct {
+	Day string `json:"day"`
+	FeedbackCounts
+}
+
+// FeedbackSourceCounts counts the retrieved sources of rated answers
+type FeedbackSourceCounts struct {
+	Sources     int64 `json:"sources"`
+	UpSources   int64 `json:"up_sources"`
+	DownSources int64 `json:"down_sources"`
+	Wrong       int64 `json:"wrong"`
+}
+
+// FeedbackKnowledgeBaseCounts counts the sources of one knowledge base
+type FeedbackKnowledgeBaseCounts struct {
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	FeedbackSourceCounts
+}
+
+// FeedbackScoreBucketCounts counts the sources whose score falls in [MinScore, MaxScore)
+type FeedbackScoreBucketCounts struct {
+	MinScore float64 `json:"min_score"`
+	MaxScore float64 `json:"max_score"`
+	FeedbackSourceCounts
+}
+
+// FeedbackWrongSource is a chunk users marked as a wrong source
+type FeedbackWrongSource struct {
+	ChunkID         string `json:"chunk_id"`
+	KnowledgeID     string `json:"knowledge_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	KnowledgeTitle  string `json:"knowledge_title"`
+	Count           int64  `json:"count"`
+}
+
+// FeedbackStats aggregates the feedback of the tenant over a date range
+type FeedbackStats struct {
+	StartDate       string                         `json:"start_date"`
+	EndDate         string                         `json:"end_date"`
+	Total           FeedbackCounts                 `json:"total"`
+	Days            []*FeedbackDayCounts           `json:"days"`
+	KnowledgeBases  []*FeedbackKnowledgeBaseCounts `json:"knowledge_bases"`
+	ScoreBuckets    []*FeedbackScoreBucketCounts   `json:"score_buckets"`
+	TopWrongSources []*FeedbackWrongSource         `json:"top_wrong_sources"`
+}
+
+// SubmitMessageFeedback creates or replaces the caller's feedback on an answer
+func (c *Client) SubmitMessageFeedback(
+	ctx context.Context, sessionID, messageID string, req *MessageFeedbackRequest,
+) (*MessageFeedback, error) {
+	path := fmt.Sprintf("/api/v1/messages/%s/%s/feedback", sessionID, messageID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseMessageFeedback(resp)
+}
+
+// GetMessageFeedback returns the caller's feedback on an answer, or nil
+func (c *Client) GetMessageFeedback(ctx context.Context, sessionID, messageID string) (*MessageFeedback, error) {
+	path := fmt.Sprintf("/api/v1/messages/%s/%s/feedback", sessionID, messageID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseMessageFeedback(resp)
+}
+
+// DeleteMessageFeedback withdraws the caller's feedback on an answer
+func (c *Client) DeleteMessageFeedback(ctx context.Context, sessionID, messageID string) error {
+	path := fmt.Sprintf("/api/v1/messages/%s/%s/feedback", sessionID, messageID)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool `json:"success"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// GetFeedbackStats aggregates the feedback of the tenant
+func (c *Client) GetFeedbackStats(ctx context.Context, query *FeedbackQuery) (*FeedbackStats, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/feedback/stats", nil, query.values())
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool           `json:"success"`
+		Data    *FeedbackStats `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// ExportFeedback exports the feedback of the tenant as JSON lines in the
+// given format: FeedbackExportRaw, FeedbackExportEval or FeedbackExportRerank.
+// Eval exports can be uploaded as an evaluation dataset.
+func (c *Client) ExportFeedback(ctx context.Context, query *FeedbackQuery, format string) ([]byte, error) {
+	values := query.values()
+	if format != "" {
+		values.Set("format", format)
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/feedback/export", nil, values)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("HTTP error %d: %s", resp.StatusCode, string(body))
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read export response: %w", err)
+	}
+	return data, nil
+}
+
+func parseMessageFeedback(resp *http.Response) (*MessageFeedback, error) {
+	var response struct {
+		Success bool             `json:"success"`
+		Data    *MessageFeedback `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
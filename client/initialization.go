@@ -40,6 +40,52 @@ type ModelCheckResult struct {
 	Message string `json:"message,omitempty"`
 }
 
+// ModelProbeCheck is the result of one probe check. Status is "passed",
+// "failed", "unsupported" (the call worked but the model lacks the
+// capability) or "skipped".
+type ModelProbeCheck struct {
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Message   string `json:"message,omitempty"`
+}
+
+// ModelProbeStreamCheck is the streaming check of a chat model
+type ModelProbeStreamCheck struct {
+	ModelProbeCheck
+	FirstTokenMs int64 `json:"first_token_ms,omitempty"`
+	Chunks       int   `json:"chunks"`
+}
+
+// ModelProbeContextCheck is the context length check of a chat model. Source
+// is "reported" by the inference server or "probed" with long prompts, in
+// which case Tokens is a lower bound.
+type ModelProbeContextCheck struct {
+	ModelProbeCheck
+	Tokens int    `json:"tokens"`
+	Source string `json:"source,omitempty"`
+}
+
+// ModelProbeEmbeddingCheck is the check of an embedding model
+type ModelProbeEmbeddingCheck struct {
+	ModelProbeCheck
+	Dimension           int `json:"dimension"`
+	ConfiguredDimension int `json:"configured_dimension,omitempty"`
+}
+
+// ModelProbeReport is the capability report of a probed model. Only the
+// checks of the probed model type are set.
+type ModelProbeReport struct {
+	ModelName  string                    `json:"model_name"`
+	ModelType  string                    `json:"model_type"`
+	Available  bool                      `json:"available"`
+	DurationMs int64                     `json:"duration_ms"`
+	Chat       *ModelProbeCheck          `json:"chat,omitempty"`
+	Streaming  *ModelProbeStreamCheck    `json:"streaming,omitempty"`
+	ToolCalls  *ModelProbeCheck          `json:"tool_calls,omitempty"`
+	Context    *ModelProbeContextCheck   `json:"context,omitempty"`
+	Embedding  *ModelProbeEmbeddingCheck `json:"embedding,omitempty"`
+}
+
 // GetInitializationConfig gets the current initialization config for a knowledge base
 func (c *Client) GetInitializationConfig(ctx context.Context, kbID string) (*InitializationConfig, error) {
 	resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v1/initialization/config/%s", kbID), nil, nil)
@@ -253,3 +299,22 @@ func (c *Client) ExtractTextRelations(ctx context.Context, params any) (json.Raw
 	}
 	return result.Data, nil
 }
+
+// ProbeModel calls an unsaved model configuration and reports its
+// capabilities. params takes the fields of the connection test endpoints plus
+// "type" (KnowledgeQA or Embedding), and optionally "probeContext" and
+// "maxContextTokens".
+func (c *Client) ProbeModel(ctx context.Context, params any) (*ModelProbeReport, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/initialization/models/probe", params, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Success bool              `json:"success"`
+		Data    *ModelProbeReport `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
@@ -0,0 +1,195 @@
+// Package client provides the implementation for interacting with the WeKnora API
+// The KBSnapshot related interfaces are used to back up knowledge bases to
+// object storage and restore them as new knowledge bases
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// KBSnapshot is a point-in-time copy of a knowledge base
+type KBSnapshot struct {
+	ID                string     `json:"id"`
+	TenantID          uint64     `json:"tenant_id"`
+	KnowledgeBaseID   string     `json:"knowledge_base_id"`
+	KnowledgeBaseName string     `json:"knowledge_base_name"`
+	Trigger           string     `json:"trigger"` // manual, scheduled
+	Status            string     `json:"status"`  // pending, running, completed, failed
+	Size              int64      `json:"size"`
+	KnowledgeCount    int        `json:"knowledge_count"`
+	ChunkCount        int        `json:"chunk_count"`
+	VectorCount       int        `json:"vector_count"`
+	VectorsIncluded   bool       `json:"vectors_included"`
+	EmbeddingModelID  string     `json:"embedding_model_id"`
+	ErrorMessage      string     `json:"error_message"`
+	CreatedAt         time.Time  `json:"created_at"`
+	CompletedAt       *time.Time `json:"completed_at"`
+}
+
+// KBSnapshotPolicy schedules snapshots of a knowledge base
+type KBSnapshotPolicy struct {
+	KnowledgeBaseID string     `json:"knowledge_base_id"`
+	Enabled         bool       `json:"enabled"`
+	IntervalHours   int        `json:"interval_hours"`
+	Retain          int        `json:"retain"` // Scheduled snapshots kept
+	NextRunAt       *time.Time `json:"next_run_at"`
+}
+
+// KBSnapshotResponse represents a snapshot response
+type KBSnapshotResponse struct {
+	Success bool       `json:"success"`
+	Data    KBSnapshot `json:"data"`
+}
+
+// KBSnapshotListResponse represents a snapshot list response
+type KBSnapshotListResponse struct {
+	Success bool         `json:"success"`
+	Data    []KBSnapshot `json:"data"`
+}
+
+// KBSnapshotPolicyResponse represents a snapshot policy response
+type KBSnapshotPolicyResponse struct {
+	Success bool             `json:"success"`
+	Data    KBSnapshotPolicy `json:"data"`
+}
+
+// CreateKBSnapshot starts a snapshot of a knowledge base
+func (c *Client) CreateKBSnapshot(ctx context.Context, knowledgeBaseID string) (*KBSnapshot, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/snapshots", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response KBSnapshotResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// ListKBSnapshots lists the snapshots of a knowledge base, newest first
+func (c *Client) ListKBSnapshots(ctx context.Context, knowledgeBaseID string) ([]KBSnapshot, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/snapshots", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response KBSnapshotListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Data, nil
+}
+
+// ListTenantKBSnapshots lists the snapshots of the tenant, including those of
+// deleted knowledge bases. knowledgeBaseID is optional
+func (c *Client) ListTenantKBSnapshots(ctx context.Context, knowledgeBaseID string) ([]KBSnapshot, error) {
+	query := url.Values{}
+	if knowledgeBaseID != "" {
+		query.Add("knowledge_base_id", knowledgeBaseID)
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/kb-snapshots", nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response KBSnapshotListResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return response.Data, nil
+}
+
+// GetKBSnapshot gets a snapshot
+func (c *Client) GetKBSnapshot(ctx context.Context, snapshotID string) (*KBSnapshot, error) {
+	path := fmt.Sprintf("/api/v1/kb-snapshots/%s", snapshotID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response KBSnapshotResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// DeleteKBSnapshot deletes a snapshot and its archive
+func (c *Client) DeleteKBSnapshot(ctx context.Context, snapshotID string) error {
+	path := fmt.Sprintf("/api/v1/kb-snapshots/%s", snapshotID)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool `json:"success"`
+	}
+
+	return parseResponse(resp, &response)
+}
+
+// RestoreKBSnapshot restores a snapshot as a new knowledge base. An empty
+// name lets the server pick one. Poll the returned task with GetKBCloneProgress
+func (c *Client) RestoreKBSnapshot(ctx context.Context, snapshotID, name string) (*KBCloneProgress, error) {
+	path := fmt.Sprintf("/api/v1/kb-snapshots/%s/restore", snapshotID)
+	request := map[string]string{"name": name}
+	resp, err := c.doRequest(ctx, http.MethodPost, path, request, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool            `json:"success"`
+		Data    KBCloneProgress `json:"data"`
+	}
+
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// GetKBSnapshotPolicy gets the snapshot schedule of a knowledge base
+func (c *Client) GetKBSnapshotPolicy(ctx context.Context, knowledgeBaseID string) (*KBSnapshotPolicy, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/snapshot-policy", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response KBSnapshotPolicyResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// UpdateKBSnapshotPolicy updates the snapshot schedule of a knowledge base
+func (c *Client) UpdateKBSnapshotPolicy(ctx context.Context, knowledgeBaseID string, policy *KBSnapshotPolicy) (*KBSnapshotPolicy, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/snapshot-policy", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, policy, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response KBSnapshotPolicyResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
@@ -16,6 +16,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 )
@@ -477,6 +478,31 @@ func (c *Client) UpdateKnowledge(ctx context.Context, knowledge *Knowledge) erro
 	return parseResponse(resp, &response)
 }
 
+// KnowledgeACL lists who may read a document at retrieval time. Groups are
+// tenant roles (viewer, contributor, admin, owner), api_key, or external SSO
+// groups written as sso:<group>.
+type KnowledgeACL struct {
+	Users  []string `json:"users"`
+	Groups []string `json:"groups"`
+}
+
+// UpdateKnowledgeACL sets the ACL of a knowledge entry; an ACL listing
+// nobody removes it. Callers outside the ACL no longer retrieve the
+// document's chunks.
+func (c *Client) UpdateKnowledgeACL(ctx context.Context, knowledgeID string, acl *KnowledgeACL) (*Knowledge, error) {
+	path := fmt.Sprintf("/api/v1/knowledge/%s/acl", knowledgeID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, acl, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response KnowledgeResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
 // ReparseKnowledge triggers re-parsing of a knowledge entry
 // This method deletes existing document content and re-parses the knowledge asynchronously.
 // It's useful when you want to refresh the knowledge content with updated parsing configurations
@@ -516,6 +542,111 @@ func (c *Client) ReparseKnowledge(ctx context.Context, knowledgeID string) (*Kno
 	return &response.Data, nil
 }
 
+// UpdateKnowledgeFile uploads a new version of the file of a file knowledge.
+// Only the chunks whose content changed are re-embedded; unchanged chunks keep
+// their IDs. metadata is merged over the existing metadata. Uploading the
+// current file again returns the knowledge unchanged.
+func (c *Client) UpdateKnowledgeFile(ctx context.Context,
+	knowledgeID string, filePath string, metadata map[string]string, customFileName string,
+) (*Knowledge, error) {
+	if knowledgeID == "" {
+		return nil, fmt.Errorf("knowledge ID cannot be empty")
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file information: %w", err)
+	}
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", fileInfo.Name())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("failed to copy file content: %w", err)
+	}
+	if metadata != nil {
+		metadataBytes, err := json.Marshal(metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize metadata: %w", err)
+		}
+		if err := writer.WriteField("metadata", string(metadataBytes)); err != nil {
+			return nil, fmt.Errorf("failed to write metadata field: %w", err)
+		}
+	}
+	if customFileName != "" {
+		if err := writer.WriteField("fileName", customFileName); err != nil {
+			return nil, fmt.Errorf("failed to write fileName field: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	path := fmt.Sprintf("/api/v1/knowledge/%s/file", knowledgeID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	c.applyAuthHeaders(ctx, req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response KnowledgeResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// KnowledgeVersion is one file version of a knowledge. The chunk counts
+// compare the version with the previous one once it has been indexed.
+type KnowledgeVersion struct {
+	ID            string    `json:"id"`
+	TenantID      uint64    `json:"tenant_id"`
+	KnowledgeID   string    `json:"knowledge_id"`
+	Version       int       `json:"version"`
+	FileName      string    `json:"file_name"`
+	FileType      string    `json:"file_type"`
+	FileSize      int64     `json:"file_size"`
+	FileHash      string    `json:"file_hash"`
+	ChunksAdded   int       `json:"chunks_added"`
+	ChunksReused  int       `json:"chunks_reused"`
+	ChunksRemoved int       `json:"chunks_removed"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ListKnowledgeVersions lists the file versions of a knowledge, newest first.
+func (c *Client) ListKnowledgeVersions(ctx context.Context, knowledgeID string) ([]KnowledgeVersion, error) {
+	if knowledgeID == "" {
+		return nil, fmt.Errorf("knowledge ID cannot be empty")
+	}
+	path := fmt.Sprintf("/api/v1/knowledge/%s/versions", knowledgeID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Success bool               `json:"success"`
+		Data    []KnowledgeVersion `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
 // CancelKnowledgeParse cancels an in-progress knowledge parse.
 // The server marks the knowledge as cancelled and best-effort dequeues
 // any pending downstream tasks (multimodal, post-process, summary,
@@ -566,6 +697,42 @@ func (c *Client) CancelKnowledgeParse(ctx context.Context, knowledgeID string) (
 	return &response.Data, nil
 }
 
+// RegenerateQuestions regenerates the questions of every text chunk of a
+// parsed knowledge entry with the knowledge base's current question generation
+// settings (count and model). The old questions and their index entries are
+// replaced asynchronously.
+//
+// Parameters:
+//   - ctx: Context for the request
+//   - knowledgeID: The ID of the knowledge entry
+//
+// Returns:
+//   - int: The number of chunks queued for regeneration
+//   - error: Error information if the request fails
+func (c *Client) RegenerateQuestions(ctx context.Context, knowledgeID string) (int, error) {
+	if knowledgeID == "" {
+		return 0, fmt.Errorf("knowledge ID cannot be empty")
+	}
+
+	path := fmt.Sprintf("/api/v1/knowledge/%s/questions/regenerate", knowledgeID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var response struct {
+		Success bool `json:"success"`
+		Data    struct {
+			ChunkCount int `json:"chunk_count"`
+		} `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return 0, err
+	}
+
+	return response.Data.ChunkCount, nil
+}
+
 // KnowledgeSpanNode mirrors one node of the server's document-parsing trace
 // tree (root → stage → subspan). Children carries nested subspans such as
 // per-image multimodal calls or LLM generations under a stage.
@@ -663,6 +830,156 @@ func (c *Client) GetKnowledgeProcessingSpans(
 	return &response.Data, nil
 }
 
+// IngestionJob is the progress of a knowledge entry's current parse attempt.
+type IngestionJob struct {
+	KnowledgeID     string     `json:"knowledge_id"`
+	KnowledgeBaseID string     `json:"knowledge_base_id"`
+	Attempt         int        `json:"attempt"`
+	State           string     `json:"state"` // queued / parsing / embedding / indexing / completed / failed / cancelled
+	Stage           string     `json:"stage,omitempty"`
+	Progress        int        `json:"progress"`
+	Priority        string     `json:"priority"` // interactive / bulk
+	Retry           int        `json:"retry"`
+	ErrorCode       string     `json:"error_code,omitempty"`
+	ErrorMessage    string     `json:"error_message,omitempty"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// GetKnowledgeJob fetches the state and progress of a knowledge entry's
+// parse job. Poll it until State is completed, failed or cancelled, or
+// subscribe to /api/v1/knowledge/{id}/job/stream for server-sent updates.
+func (c *Client) GetKnowledgeJob(ctx context.Context, knowledgeID string) (*IngestionJob, error) {
+	if knowledgeID == "" {
+		return nil, fmt.Errorf("knowledge ID cannot be empty")
+	}
+
+	path := fmt.Sprintf("/api/v1/knowledge/%s/job", knowledgeID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool         `json:"success"`
+		Data    IngestionJob `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
+
+// ChunkImportError describes a line of a chunk import file that could not be imported.
+type ChunkImportError struct {
+	Line       int    `json:"line"`
+	DocumentID string `json:"document_id,omitempty"`
+	Message    string `json:"message"`
+}
+
+// ChunkImportProgress is the progress of a chunk import task.
+type ChunkImportProgress struct {
+	TaskID          string             `json:"task_id"`
+	KBID            string             `json:"kb_id"`
+	Status          string             `json:"status"` // pending / processing / completed / failed
+	Progress        int                `json:"progress"`
+	BytesRead       int64              `json:"bytes_read"`
+	FileSize        int64              `json:"file_size"`
+	Lines           int                `json:"lines"`
+	Documents       int                `json:"documents"`
+	Chunks          int                `json:"chunks"`
+	FailedDocuments int                `json:"failed_documents"`
+	Errors          []ChunkImportError `json:"errors,omitempty"`
+	Message         string             `json:"message"`
+	Error           string             `json:"error"`
+	CreatedAt       int64              `json:"created_at"`
+	UpdatedAt       int64              `json:"updated_at"`
+}
+
+// ImportChunks uploads a JSONL file of pre-chunked (optionally pre-embedded)
+// documents into a knowledge base, bypassing document parsing. Each line is
+// {"document_id", "content", "chunk_index", "title", "source", "metadata",
+// "embedding"}; lines of one document must be contiguous. batchSize and
+// rateLimit (chunks per second) may be 0 for the server defaults.
+// Poll GetChunkImportProgress with the returned task ID.
+func (c *Client) ImportChunks(ctx context.Context,
+	knowledgeBaseID string, filePath string, batchSize, rateLimit int,
+) (*ChunkImportProgress, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", filepath.Base(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create form file: %w", err)
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("failed to copy file content: %w", err)
+	}
+	if batchSize > 0 {
+		if err := writer.WriteField("batch_size", strconv.Itoa(batchSize)); err != nil {
+			return nil, fmt.Errorf("failed to write batch_size field: %w", err)
+		}
+	}
+	if rateLimit > 0 {
+		if err := writer.WriteField("rate_limit", strconv.Itoa(rateLimit)); err != nil {
+			return nil, fmt.Errorf("failed to write rate_limit field: %w", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close writer: %w", err)
+	}
+
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/knowledge/chunks-import", knowledgeBaseID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	c.applyAuthHeaders(ctx, req)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var response struct {
+		Success bool                `json:"success"`
+		Data    ChunkImportProgress `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GetChunkImportProgress fetches the progress of a chunk import task.
+func (c *Client) GetChunkImportProgress(ctx context.Context,
+	knowledgeBaseID string, taskID string,
+) (*ChunkImportProgress, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/knowledge/chunks-import/%s", knowledgeBaseID, taskID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                `json:"success"`
+		Data    ChunkImportProgress `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
 // UpdateChunk updates a chunk's information
 // Updates information for a specific chunk under a knowledge document
 // Parameters:
@@ -785,6 +1102,9 @@ type MoveKnowledgeRequest struct {
 	SourceKBID   string   `json:"source_kb_id"`
 	TargetKBID   string   `json:"target_kb_id"`
 	Mode         string   `json:"mode"` // "reuse_vectors" or "reparse"
+	// Dedupe deletes the items whose file already exists in the target
+	// instead of moving them
+	Dedupe bool `json:"dedupe,omitempty"`
 }
 
 // MoveKnowledgeResponse represents the response from move knowledge API
@@ -813,15 +1133,43 @@ func (c *Client) MoveKnowledge(ctx context.Context, req *MoveKnowledgeRequest) (
 	return result.Data, nil
 }
 
+// MergeKnowledgeBaseRequest contains the parameters for merging one knowledge base into another
+type MergeKnowledgeBaseRequest struct {
+	SourceKBID string `json:"source_kb_id"`
+	TargetKBID string `json:"target_kb_id"`
+	Mode       string `json:"mode"` // "reuse_vectors" or "reparse"
+}
+
+// MergeKnowledgeBase moves every knowledge of the source knowledge base into
+// the target (async task). Items whose file already exists in the target are
+// deleted from the source instead. Poll with GetKnowledgeMoveProgress
+func (c *Client) MergeKnowledgeBase(ctx context.Context, req *MergeKnowledgeBaseRequest) (*MoveKnowledgeResponse, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/knowledge/merge", req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Success bool                   `json:"success"`
+		Data    *MoveKnowledgeResponse `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
 // KnowledgeMoveProgress represents the progress of a knowledge move task
 type KnowledgeMoveProgress struct {
-	TaskID    string `json:"task_id"`
-	Status    string `json:"status"`
-	Progress  int    `json:"progress"`
-	Total     int    `json:"total"`
-	Processed int    `json:"processed"`
-	Message   string `json:"message"`
-	Error     string `json:"error,omitempty"`
+	TaskID     string `json:"task_id"`
+	Status     string `json:"status"`
+	Progress   int    `json:"progress"`
+	Total      int    `json:"total"`
+	Processed  int    `json:"processed"`
+	Failed     int    `json:"failed"`
+	Duplicates int    `json:"duplicates"` // Items deleted as duplicates of the target
+	Message    string `json:"message"`
+	Error      string `json:"error,omitempty"`
 }
 
 // GetKnowledgeMoveProgress gets the progress of a knowledge move task
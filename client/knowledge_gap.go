@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// KnowledgeGap is a query that searched a knowledge base and retrieved
+// nothing, with how many times it was asked
+type KnowledgeGap struct {
+	ID              string    `json:"id"`
+	TenantID        uint64    `json:"tenant_id"`
+	KnowledgeBaseID string    `json:"knowledge_base_id"`
+	Query           string    `json:"query"`
+	Count           int64     `json:"count"`
+	FirstSeenAt     time.Time `json:"first_seen_at"`
+	LastSeenAt      time.Time `json:"last_seen_at"`
+}
+
+// KnowledgeGapCluster is a group of similar gap queries, represented by
+// its most frequent query
+type KnowledgeGapCluster struct {
+	Query       string          `json:"query"`
+	Count       int64           `json:"count"`
+	FirstSeenAt time.Time       `json:"first_seen_at"`
+	LastSeenAt  time.Time       `json:"last_seen_at"`
+	Gaps        []*KnowledgeGap `json:"gaps"`
+}
+
+// KnowledgeGapReport groups the gaps of a knowledge base by similarity
+type KnowledgeGapReport struct {
+	KnowledgeBaseID string  `json:"knowledge_base_id"`
+	Threshold       float64 `json:"threshold"`
+	Gaps            int64   `json:"gaps"`
+	// Clustered is false when the queries could not be embedded and each
+	// cluster holds one query
+	Clustered bool                   `json:"clustered"`
+	Truncated bool                   `json:"truncated"`
+	Clusters  []*KnowledgeGapCluster `json:"clusters"`
+}
+
+// KnowledgeGapReportOptions tunes a knowledge gap report; zero values use
+// the server defaults
+type KnowledgeGapReportOptions struct {
+	// Threshold is the similarity at which queries are clustered, 0.5 to 1
+	Threshold float64
+	// Limit is the number of clusters to return, at most 100
+	Limit int
+}
+
+// GetKnowledgeGapReport returns the queries that retrieved nothing from a
+// knowledge base, clustered by similarity
+func (c *Client) GetKnowledgeGapReport(
+	ctx context.Context, knowledgeBaseID string, options *KnowledgeGapReportOptions,
+) (*KnowledgeGapReport, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/knowledge-gaps", knowledgeBaseID)
+	query := url.Values{}
+	if options != nil {
+		if options.Threshold > 0 {
+			query.Set("threshold", strconv.FormatFloat(options.Threshold, 'f', -1, 64))
+		}
+		if options.Limit > 0 {
+			query.Set("limit", strconv.Itoa(options.Limit))
+		}
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool                `json:"success"`
+		Data    *KnowledgeGapReport `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// DismissKnowledgeGaps deletes gaps of a knowledge base once the missing
+// content was added and returns how many were deleted
+func (c *Client) DismissKnowledgeGaps(ctx context.Context, knowledgeBaseID string, gapIDs []string) (int64, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/knowledge-gaps/dismiss", knowledgeBaseID)
+	body := map[string][]string{"gap_ids": gapIDs}
+	resp, err := c.doRequest(ctx, http.MethodPost, path, body, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var response struct {
+		Success bool `json:"success"`
+		Data    struct {
+			Deleted int64 `json:"deleted"`
+		} `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return 0, err
+	}
+	return response.Data.Deleted, nil
+}
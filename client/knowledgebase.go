@@ -30,6 +30,7 @@ type KnowledgeBase struct {
 	StorageProviderConfig *StorageProviderConfig `json:"storage_provider_config"`
 	StorageConfig         StorageConfig          `json:"storage_config"`
 	ExtractConfig         *ExtractConfig         `json:"extract_config"`
+	AutoTagConfig         *AutoTagConfig         `json:"auto_tag_config"`
 	CreatedAt             time.Time             `json:"created_at"`
 	UpdatedAt             time.Time             `json:"updated_at"`
 	// Computed fields (not stored in database)
@@ -44,6 +45,8 @@ type KnowledgeBaseConfig struct {
 	ChunkingConfig        ChunkingConfig        `json:"chunking_config"`
 	ImageProcessingConfig ImageProcessingConfig `json:"image_processing_config"`
 	FAQConfig             *FAQConfig            `json:"faq_config"`
+	// AutoTagConfig is left unchanged when nil
+	AutoTagConfig *AutoTagConfig `json:"auto_tag_config,omitempty"`
 }
 
 // ChunkingConfig represents document chunking configuration
@@ -118,6 +121,23 @@ type ParserEngineRule struct {
 type QuestionGenerationConfig struct {
 	Enabled         bool `json:"enabled"`
 	QuestionCount   int  `json:"question_count"`
+	// ModelID is the chat model generating the questions; empty uses the
+	// knowledge base's summary model.
+	ModelID string `json:"model_id,omitempty"`
+}
+
+// AutoTagConfig controls automatic tagging of documents against the
+// knowledge base's tags once their summary is generated.
+type AutoTagConfig struct {
+	Enabled bool `json:"enabled"`
+	// MaxTags caps the tags attached to one document (default 3, max 10)
+	MaxTags int `json:"max_tags"`
+	// ModelID is the chat model classifying documents; empty uses the
+	// knowledge base's summary model.
+	ModelID string `json:"model_id,omitempty"`
+	// RetagOnChange re-tags all documents shortly after a tag is created,
+	// renamed or deleted.
+	RetagOnChange bool `json:"retag_on_change"`
 }
 
 // ASRConfig represents automatic speech recognition settings for audio files.
@@ -166,20 +186,22 @@ type KnowledgeBaseListResponse struct {
 //
 // Channel grouping: 0-1 primary text channels (vector + keyword);
 // 2-5 enrichment chunks (added in addition to primary matches, score=0);
-// 6-9 alternate sources (graph DB, web search, raw load, data analysis).
+// 6-10 alternate sources (graph DB, web search, raw load, data analysis,
+// SQL query results).
 type MatchType int
 
 const (
-	MatchTypeVector   MatchType = 0 // server: MatchTypeEmbedding
-	MatchTypeKeyword  MatchType = 1 // server: MatchTypeKeywords
-	MatchTypeNearby   MatchType = 2 // server: MatchTypeNearByChunk
-	MatchTypeHistory  MatchType = 3 // server: MatchTypeHistory
-	MatchTypeParent   MatchType = 4 // server: MatchTypeParentChunk
-	MatchTypeRelation MatchType = 5 // server: MatchTypeRelationChunk
-	MatchTypeGraph    MatchType = 6 // server: MatchTypeGraph
-	MatchTypeWeb      MatchType = 7 // server: MatchTypeWebSearch
-	MatchTypeDirect   MatchType = 8 // server: MatchTypeDirectLoad — chunk loaded by ID without scoring
-	MatchTypeData     MatchType = 9 // server: MatchTypeDataAnalysis — produced by analytical pipeline, not retrieval
+	MatchTypeVector   MatchType = 0  // server: MatchTypeEmbedding
+	MatchTypeKeyword  MatchType = 1  // server: MatchTypeKeywords
+	MatchTypeNearby   MatchType = 2  // server: MatchTypeNearByChunk
+	MatchTypeHistory  MatchType = 3  // server: MatchTypeHistory
+	MatchTypeParent   MatchType = 4  // server: MatchTypeParentChunk
+	MatchTypeRelation MatchType = 5  // server: MatchTypeRelationChunk
+	MatchTypeGraph    MatchType = 6  // server: MatchTypeGraph
+	MatchTypeWeb      MatchType = 7  // server: MatchTypeWebSearch
+	MatchTypeDirect   MatchType = 8  // server: MatchTypeDirectLoad — chunk loaded by ID without scoring
+	MatchTypeData     MatchType = 9  // server: MatchTypeDataAnalysis — produced by analytical pipeline, not retrieval
+	MatchTypeSQL      MatchType = 10 // server: MatchTypeText2SQL — result table of a generated SQL query
 )
 
 // SearchResult represents search result.
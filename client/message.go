@@ -41,17 +41,52 @@ type AgentStep struct {
 
 // Message message information
 type Message struct {
-	ID                  string          `json:"id"`
-	SessionID           string          `json:"session_id"`
-	RequestID           string          `json:"request_id"`
-	Content             string          `json:"content"`
-	Role                string          `json:"role"`
-	KnowledgeReferences []*SearchResult `json:"knowledge_references"`
-	AgentSteps          []AgentStep     `json:"agent_steps,omitempty"` // Agent execution steps (only for assistant messages)
-	IsCompleted         bool            `json:"is_completed"`
-	Channel             string          `json:"channel,omitempty"` // Source channel: "web", "api", "im", etc.
-	CreatedAt           time.Time       `json:"created_at"`
-	UpdatedAt           time.Time       `json:"updated_at"`
+	ID                  string              `json:"id"`
+	SessionID           string              `json:"session_id"`
+	RequestID           string              `json:"request_id"`
+	Content             string              `json:"content"`
+	Role                string              `json:"role"`
+	KnowledgeReferences []*SearchResult     `json:"knowledge_references"`
+	Citations           []Citation          `json:"citations,omitempty"`    // Sources cited inline as [n] in the answer
+	Verification        *AnswerVerification `json:"verification,omitempty"` // Answer claims checked against the retrieved context
+	Metadata            *MessageMetadata    `json:"metadata,omitempty"`     // Generation metadata, such as the chat model that answered
+	AgentSteps          []AgentStep         `json:"agent_steps,omitempty"`  // Agent execution steps (only for assistant messages)
+	IsCompleted         bool                `json:"is_completed"`
+	Channel             string              `json:"channel,omitempty"` // Source channel: "web", "api", "im", etc.
+	CreatedAt           time.Time           `json:"created_at"`
+	UpdatedAt           time.Time           `json:"updated_at"`
+}
+
+// MessageMetadata records how an assistant message was generated
+type MessageMetadata struct {
+	ModelID      string `json:"model_id"`
+	ModelName    string `json:"model_name,omitempty"`
+	FallbackFrom string `json:"fallback_from,omitempty"` // Chat model that failed before the answering one took over
+}
+
+// Citation maps an inline [n] marker of an answer to the chunk it cites
+type Citation struct {
+	Index           int    `json:"index"`
+	ChunkID         string `json:"chunk_id"`
+	KnowledgeID     string `json:"knowledge_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"`
+	KnowledgeTitle  string `json:"knowledge_title,omitempty"`
+}
+
+// AnswerVerification is the outcome of checking an answer's claims against
+// the retrieved context
+type AnswerVerification struct {
+	Verdict     string         `json:"verdict"` // supported, partially_supported or unsupported
+	Claims      []ClaimVerdict `json:"claims"`
+	Regenerated bool           `json:"regenerated,omitempty"` // The answer was replaced by a regenerated one
+	Disclaimer  string         `json:"disclaimer,omitempty"`  // Text appended to the answer
+}
+
+// ClaimVerdict is the judgement of one claim of an answer
+type ClaimVerdict struct {
+	Claim     string `json:"claim"`
+	Supported bool   `json:"supported"`
+	Reason    string `json:"reason,omitempty"`
 }
 
 // MessageListResponse message list response
@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// RetentionPolicy deletes data once it is older than configured. A tenant
+// has one tenant-wide policy (empty KnowledgeBaseID) for sessions, messages
+// and memory episodes, and one policy per knowledge base for its documents.
+// A zero period keeps that kind of data forever.
+type RetentionPolicy struct {
+	ID                     string     `json:"id"`
+	TenantID               uint64     `json:"tenant_id"`
+	KnowledgeBaseID        string     `json:"knowledge_base_id"`
+	Enabled                bool       `json:"enabled"`
+	SessionRetentionDays   int        `json:"session_retention_days"`
+	MemoryRetentionDays    int        `json:"memory_retention_days"`
+	KnowledgeRetentionDays int        `json:"knowledge_retention_days"`
+	KnowledgeExpiresAt     *time.Time `json:"knowledge_expires_at"`
+	NextRunAt              *time.Time `json:"next_run_at"`
+	LastRunAt              *time.Time `json:"last_run_at"`
+	CreatedAt              time.Time  `json:"created_at"`
+	UpdatedAt              time.Time  `json:"updated_at"`
+}
+
+// RetentionPolicyRequest replaces the settings of a retention policy. The
+// session and memory periods apply to the tenant-wide policy only, the
+// knowledge fields to knowledge base policies only.
+type RetentionPolicyRequest struct {
+	Enabled                bool       `json:"enabled"`
+	SessionRetentionDays   int        `json:"session_retention_days,omitempty"`
+	MemoryRetentionDays    int        `json:"memory_retention_days,omitempty"`
+	KnowledgeRetentionDays int        `json:"knowledge_retention_days,omitempty"`
+	KnowledgeExpiresAt     *time.Time `json:"knowledge_expires_at,omitempty"`
+}
+
+// RetentionReport tells what a retention policy would delete if it ran now.
+type RetentionReport struct {
+	PolicyID        string     `json:"policy_id"`
+	KnowledgeBaseID string     `json:"knowledge_base_id,omitempty"`
+	Enabled         bool       `json:"enabled"`
+	DryRun          bool       `json:"dry_run"`
+	SessionCutoff   *time.Time `json:"session_cutoff,omitempty"`
+	MemoryCutoff    *time.Time `json:"memory_cutoff,omitempty"`
+	KnowledgeCutoff *time.Time `json:"knowledge_cutoff,omitempty"`
+	Sessions        int64      `json:"sessions"`
+	Messages        int64      `json:"messages"`
+	Episodes        int64      `json:"episodes"`
+	Knowledge       int64      `json:"knowledge"`
+}
+
+// RetentionPolicyResponse wraps a single retention policy response.
+type RetentionPolicyResponse struct {
+	Success bool             `json:"success"`
+	Data    *RetentionPolicy `json:"data"`
+}
+
+// RetentionPreviewResponse wraps the retention preview response.
+type RetentionPreviewResponse struct {
+	Success bool               `json:"success"`
+	Data    []*RetentionReport `json:"data"`
+}
+
+// GetRetentionPolicy gets the tenant-wide retention policy.
+func (c *Client) GetRetentionPolicy(ctx context.Context) (*RetentionPolicy, error) {
+	return c.retentionPolicy(ctx, http.MethodGet, "/api/v1/retention-policy", nil)
+}
+
+// UpdateRetentionPolicy replaces the tenant-wide retention policy.
+func (c *Client) UpdateRetentionPolicy(ctx context.Context, req *RetentionPolicyRequest) (*RetentionPolicy, error) {
+	return c.retentionPolicy(ctx, http.MethodPut, "/api/v1/retention-policy", req)
+}
+
+// GetKnowledgeBaseRetentionPolicy gets the retention policy of a knowledge base.
+func (c *Client) GetKnowledgeBaseRetentionPolicy(ctx context.Context, knowledgeBaseID string) (*RetentionPolicy, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/retention-policy", knowledgeBaseID)
+	return c.retentionPolicy(ctx, http.MethodGet, path, nil)
+}
+
+// UpdateKnowledgeBaseRetentionPolicy replaces the retention policy of a knowledge base.
+func (c *Client) UpdateKnowledgeBaseRetentionPolicy(
+	ctx context.Context, knowledgeBaseID string, req *RetentionPolicyRequest,
+) (*RetentionPolicy, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/retention-policy", knowledgeBaseID)
+	return c.retentionPolicy(ctx, http.MethodPut, path, req)
+}
+
+// PreviewRetention reports what each retention policy of the tenant would
+// delete if it ran now, without deleting anything.
+func (c *Client) PreviewRetention(ctx context.Context) ([]*RetentionReport, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/retention-policy/preview", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response RetentionPreviewResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+func (c *Client) retentionPolicy(ctx context.Context, method, path string, req *RetentionPolicyRequest) (*RetentionPolicy, error) {
+	var body interface{}
+	if req != nil {
+		body = req
+	}
+	resp, err := c.doRequest(ctx, method, path, body, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response RetentionPolicyResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
@@ -0,0 +1,335 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// EvalExpectedSource identifies a source a question expects in the
+// retrieval results. Set exactly one field: a chunk ID matches that chunk, a
+// knowledge ID or a document title or file name matches any chunk of the
+// document.
+type EvalExpectedSource struct {
+	ChunkID     string `json:"chunk_id,omitempty"`
+	KnowledgeID string `json:"knowledge_id,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+// EvalDatasetItem is one question of a golden dataset.
+type EvalDatasetItem struct {
+	ID              string               `json:"id,omitempty"`
+	Position        int                  `json:"position,omitempty"`
+	Question        string               `json:"question"`
+	ExpectedAnswer  string               `json:"expected_answer,omitempty"`
+	ExpectedSources []EvalExpectedSource `json:"expected_sources,omitempty"`
+}
+
+// EvalDataset is a golden dataset of questions with expected sources and
+// reference answers.
+type EvalDataset struct {
+	ID          string             `json:"id"`
+	TenantID    uint64             `json:"tenant_id"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	ItemCount   int                `json:"item_count"`
+	CreatedBy   string             `json:"created_by"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	Items       []*EvalDatasetItem `json:"items,omitempty"`
+}
+
+// EvalDatasetRequest creates a golden dataset of at most 1000 items.
+type EvalDatasetRequest struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Items       []*EvalDatasetItem `json:"items"`
+}
+
+// EvalRunConfig is the retrieval configuration an evaluation run tests.
+// Unset fields take the server's conversation defaults.
+type EvalRunConfig struct {
+	// Mode is "retrieval" (default) or "rag"
+	Mode             string   `json:"mode,omitempty"`
+	TopK             int      `json:"top_k,omitempty"`
+	EmbeddingTopK    int      `json:"embedding_top_k,omitempty"`
+	VectorThreshold  *float64 `json:"vector_threshold,omitempty"`
+	KeywordThreshold *float64 `json:"keyword_threshold,omitempty"`
+	RerankModelID    string   `json:"rerank_model_id,omitempty"`
+	RerankTopK       int      `json:"rerank_top_k,omitempty"`
+	RerankThreshold  *float64 `json:"rerank_threshold,omitempty"`
+	ChatModelID      string   `json:"chat_model_id,omitempty"`
+	JudgeModelID     string   `json:"judge_model_id,omitempty"`
+	// ChunkingConfig is the knowledge base's chunking when the run started;
+	// set by the server
+	ChunkingConfig map[string]interface{} `json:"chunking_config,omitempty"`
+}
+
+// EvalRunRequest starts an evaluation run.
+type EvalRunRequest struct {
+	DatasetID       string        `json:"dataset_id"`
+	KnowledgeBaseID string        `json:"knowledge_base_id"`
+	Name            string        `json:"name,omitempty"`
+	Config          EvalRunConfig `json:"config"`
+}
+
+// EvalMetrics are the metrics of a question or, averaged, of a run. The
+// answer metrics are only set in rag mode.
+type EvalMetrics struct {
+	RecallAtK       float64  `json:"recall_at_k"`
+	MRR             float64  `json:"mrr"`
+	NDCGAtK         float64  `json:"ndcg_at_k"`
+	HitRateAtK      float64  `json:"hit_rate_at_k"`
+	Faithfulness    *float64 `json:"faithfulness,omitempty"`
+	AnswerRelevance *float64 `json:"answer_relevance,omitempty"`
+	AvgLatencyMs    int64    `json:"avg_latency_ms"`
+}
+
+// EvalRun is one execution of a golden dataset against a knowledge base.
+type EvalRun struct {
+	ID              string        `json:"id"`
+	TenantID        uint64        `json:"tenant_id"`
+	DatasetID       string        `json:"dataset_id"`
+	KnowledgeBaseID string        `json:"knowledge_base_id"`
+	Name            string        `json:"name"`
+	Status          string        `json:"status"` // pending, running, completed, failed
+	Config          EvalRunConfig `json:"config"`
+	Metrics         *EvalMetrics  `json:"metrics"`
+	Total           int           `json:"total"`
+	Finished        int           `json:"finished"`
+	Failed          int           `json:"failed"`
+	ErrMsg          string        `json:"err_msg"`
+	CreatedBy       string        `json:"created_by"`
+	StartedAt       *time.Time    `json:"started_at"`
+	FinishedAt      *time.Time    `json:"finished_at"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
+}
+
+// EvalRetrievedSource is one ranked result of a question.
+type EvalRetrievedSource struct {
+	ChunkID     string  `json:"chunk_id"`
+	KnowledgeID string  `json:"knowledge_id"`
+	Title       string  `json:"title"`
+	Score       float64 `json:"score"`
+	// Expected is the index of the expected source the result matched, or -1
+	Expected int `json:"expected"`
+}
+
+// EvalRunResult is the outcome of one question in a run.
+type EvalRunResult struct {
+	ID              string                `json:"id"`
+	RunID           string                `json:"run_id"`
+	ItemID          string                `json:"item_id"`
+	Position        int                   `json:"position"`
+	Question        string                `json:"question"`
+	ExpectedSources int                   `json:"expected_sources"`
+	Retrieved       []EvalRetrievedSource `json:"retrieved"`
+	Metrics         EvalMetrics           `json:"metrics"`
+	Answer          string                `json:"answer"`
+	JudgeReason     string                `json:"judge_reason"`
+	ErrMsg          string                `json:"err_msg"`
+	CreatedAt       time.Time             `json:"created_at"`
+}
+
+// EvalMetricsDelta is a run's metrics minus the baseline's.
+type EvalMetricsDelta struct {
+	RunID           string   `json:"run_id"`
+	RecallAtK       float64  `json:"recall_at_k"`
+	MRR             float64  `json:"mrr"`
+	NDCGAtK         float64  `json:"ndcg_at_k"`
+	HitRateAtK      float64  `json:"hit_rate_at_k"`
+	Faithfulness    *float64 `json:"faithfulness,omitempty"`
+	AnswerRelevance *float64 `json:"answer_relevance,omitempty"`
+	AvgLatencyMs    int64    `json:"avg_latency_ms"`
+}
+
+// EvalItemComparison lists the metrics of one question in each compared
+// run; an entry is nil when the question failed in that run.
+type EvalItemComparison struct {
+	ItemID   string         `json:"item_id"`
+	Question string         `json:"question"`
+	Metrics  []*EvalMetrics `json:"metrics"`
+}
+
+// EvalRunComparison compares runs against the first of them.
+type EvalRunComparison struct {
+	BaselineID string                `json:"baseline_id"`
+	Runs       []*EvalRun            `json:"runs"`
+	Deltas     []*EvalMetricsDelta   `json:"deltas"`
+	Items      []*EvalItemComparison `json:"items,omitempty"`
+}
+
+// CreateEvalDataset uploads a golden dataset.
+func (c *Client) CreateEvalDataset(ctx context.Context, req *EvalDatasetRequest) (*EvalDataset, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/eval-datasets", req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool         `json:"success"`
+		Data    *EvalDataset `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// ListEvalDatasets lists the golden datasets of the tenant, without items.
+func (c *Client) ListEvalDatasets(ctx context.Context) ([]*EvalDataset, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/eval-datasets", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool           `json:"success"`
+		Data    []*EvalDataset `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetEvalDataset gets a golden dataset with its items.
+func (c *Client) GetEvalDataset(ctx context.Context, id string) (*EvalDataset, error) {
+	path := fmt.Sprintf("/api/v1/eval-datasets/%s", id)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool         `json:"success"`
+		Data    *EvalDataset `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// DeleteEvalDataset deletes a golden dataset with all its runs.
+func (c *Client) DeleteEvalDataset(ctx context.Context, id string) error {
+	path := fmt.Sprintf("/api/v1/eval-datasets/%s", id)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool `json:"success"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// CreateEvalRun starts an evaluation run. The run executes asynchronously;
+// poll GetEvalRun until its status is completed or failed.
+func (c *Client) CreateEvalRun(ctx context.Context, req *EvalRunRequest) (*EvalRun, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/eval-runs", req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseEvalRun(resp)
+}
+
+// ListEvalRuns lists the evaluation runs of the tenant, newest first, or
+// only those of one dataset when datasetID is set.
+func (c *Client) ListEvalRuns(ctx context.Context, datasetID string) ([]*EvalRun, error) {
+	query := url.Values{}
+	if datasetID != "" {
+		query.Set("dataset_id", datasetID)
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/eval-runs", nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool       `json:"success"`
+		Data    []*EvalRun `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetEvalRun gets the status, progress and metrics of an evaluation run.
+func (c *Client) GetEvalRun(ctx context.Context, id string) (*EvalRun, error) {
+	path := fmt.Sprintf("/api/v1/eval-runs/%s", id)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return parseEvalRun(resp)
+}
+
+// ListEvalRunResults lists the per-question results of an evaluation run.
+func (c *Client) ListEvalRunResults(ctx context.Context, id string) ([]*EvalRunResult, error) {
+	path := fmt.Sprintf("/api/v1/eval-runs/%s/results", id)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool             `json:"success"`
+		Data    []*EvalRunResult `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// DeleteEvalRun deletes an evaluation run with its results.
+func (c *Client) DeleteEvalRun(ctx context.Context, id string) error {
+	path := fmt.Sprintf("/api/v1/eval-runs/%s", id)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response struct {
+		Success bool `json:"success"`
+	}
+	return parseResponse(resp, &response)
+}
+
+// CompareEvalRuns compares completed evaluation runs against the first of
+// them, the baseline.
+func (c *Client) CompareEvalRuns(ctx context.Context, runIDs ...string) (*EvalRunComparison, error) {
+	query := url.Values{}
+	query.Set("run_ids", strings.Join(runIDs, ","))
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/eval-runs/compare", nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Success bool               `json:"success"`
+		Data    *EvalRunComparison `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+func parseEvalRun(resp *http.Response) (*EvalRun, error) {
+	var response struct {
+		Success bool     `json:"success"`
+		Data    *EvalRun `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
@@ -37,18 +37,86 @@ type SummaryConfig struct {
 // Sessions are now knowledge-base-independent and serve as conversation containers.
 // All configuration comes from custom agent at query time.
 type CreateSessionRequest struct {
-	Title       string `json:"title"`       // Session title (optional)
-	Description string `json:"description"` // Session description (optional)
+	Title          string               `json:"title"`                     // Session title (optional)
+	Description    string               `json:"description"`               // Session description (optional)
+	MemoryPolicy   *MemoryPolicy        `json:"memory_policy,omitempty"`   // When turns are stored in memory (optional)
+	RerankConfig   *SessionRerankConfig `json:"rerank_config,omitempty"`   // Rerank on/off and top-N (optional)
+	PipelineConfig *PipelineConfig      `json:"pipeline_config,omitempty"` // Per-stage knowledge QA settings (optional)
+}
+
+// SessionRerankConfig turns the rerank stage on or off and sets its top-N
+// for a session. Unset fields keep the agent's settings.
+type SessionRerankConfig struct {
+	Enabled *bool `json:"enabled,omitempty"`
+	TopN    int   `json:"top_n,omitempty"` // Number of reranked results kept, at most 100
+}
+
+// PipelineConfig sets the knowledge QA pipeline stages for a session, or
+// for a tenant through the "pipeline-config" tenant KV key. Unset fields
+// keep the settings of the level below (agent, then tenant).
+type PipelineConfig struct {
+	Rewrite    *PipelineRewriteConfig    `json:"rewrite,omitempty"`
+	Retrieval  *PipelineRetrievalConfig  `json:"retrieval,omitempty"`
+	Rerank     *PipelineRerankConfig     `json:"rerank,omitempty"`
+	Memory     *PipelineMemoryConfig     `json:"memory,omitempty"`
+	History    *PipelineHistoryConfig    `json:"history,omitempty"`
+	Generation *PipelineGenerationConfig `json:"generation,omitempty"`
+}
+
+// PipelineRewriteConfig configures the query rewrite stage
+type PipelineRewriteConfig struct {
+	Enabled        *bool `json:"enabled,omitempty"`
+	QueryExpansion *bool `json:"query_expansion,omitempty"`
+}
+
+// PipelineRetrievalConfig configures the chunk search stage
+type PipelineRetrievalConfig struct {
+	TopK             int      `json:"top_k,omitempty"`             // At most 200
+	VectorThreshold  *float64 `json:"vector_threshold,omitempty"`  // 0-1
+	KeywordThreshold *float64 `json:"keyword_threshold,omitempty"` // 0-1
+}
+
+// PipelineRerankConfig configures the rerank stage
+type PipelineRerankConfig struct {
+	Enabled   *bool    `json:"enabled,omitempty"`
+	TopK      int      `json:"top_k,omitempty"`     // At most 100
+	Threshold *float64 `json:"threshold,omitempty"` // -10 to 10
+}
+
+// PipelineMemoryConfig configures the long-term memory stages
+type PipelineMemoryConfig struct {
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// PipelineHistoryConfig configures the history loading stage
+type PipelineHistoryConfig struct {
+	MaxRounds *int `json:"max_rounds,omitempty"` // 0-50, 0 sends no history
+}
+
+// PipelineGenerationConfig configures the answer generation stage
+type PipelineGenerationConfig struct {
+	FallbackModelIDs []string `json:"fallback_model_ids,omitempty"` // Chat models retried in order on rate limits, timeouts and server errors; at most 5
+}
+
+// MemoryPolicy controls when a session's turns are stored in the user's
+// long-term memory. Trigger is one of "every_turn" (default),
+// "every_n_turns", "session_end", "memorable" or "explicit".
+type MemoryPolicy struct {
+	Trigger     string `json:"trigger"`
+	EveryNTurns int    `json:"every_n_turns,omitempty"` // Batch size of "every_n_turns", 5 by default
 }
 
 // Session session information
 type Session struct {
-	ID          string `json:"id"`
-	TenantID    uint64 `json:"tenant_id"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	CreatedAt   string `json:"created_at"`
-	UpdatedAt   string `json:"updated_at"`
+	ID             string               `json:"id"`
+	TenantID       uint64               `json:"tenant_id"`
+	Title          string               `json:"title"`
+	Description    string               `json:"description"`
+	MemoryPolicy   *MemoryPolicy        `json:"memory_policy,omitempty"`
+	RerankConfig   *SessionRerankConfig `json:"rerank_config,omitempty"`
+	PipelineConfig *PipelineConfig      `json:"pipeline_config,omitempty"`
+	CreatedAt      string               `json:"created_at"`
+	UpdatedAt      string               `json:"updated_at"`
 }
 
 // SessionResponse session response
@@ -219,6 +287,7 @@ type KnowledgeQARequest struct {
 	DisableTitle     bool              `json:"disable_title"`      // Whether to disable auto title generation
 	Images           []ImageAttachment `json:"images,omitempty"`   // Attached images for multimodal chat
 	Channel          string            `json:"channel,omitempty"`  // Source channel: "web", "api", "im", etc.
+	Debug            bool              `json:"debug,omitempty"`    // Stream a per-stage pipeline trace first; needs the tenant admin role
 }
 
 // LLMToolCall represents a function/tool call from the LLM
@@ -239,6 +308,10 @@ type ResponseType string
 const (
 	ResponseTypeAnswer       ResponseType = "answer"
 	ResponseTypeReferences   ResponseType = "references"
+	ResponseTypeCitations    ResponseType = "citations"
+	ResponseTypeVerification ResponseType = "verification"
+	ResponseTypeFollowUps    ResponseType = "follow_ups"
+	ResponseTypeDebugTrace   ResponseType = "debug_trace"
 	ResponseTypeThinking     ResponseType = "thinking"
 	ResponseTypeToolCall     ResponseType = "tool_call"
 	ResponseTypeToolResult   ResponseType = "tool_result"
@@ -256,12 +329,54 @@ type StreamResponse struct {
 	Content             string                 `json:"content"`                        // Current content fragment
 	Done                bool                   `json:"done"`                           // Whether completed
 	KnowledgeReferences []*SearchResult        `json:"knowledge_references,omitempty"` // Knowledge references
+	Citations           []Citation             `json:"citations,omitempty"`            // Sources cited inline as [n] (citations event)
+	Verification        *AnswerVerification    `json:"verification,omitempty"`         // Answer verification (verification event)
+	FollowUpQuestions   []string               `json:"follow_up_questions,omitempty"`  // Suggested follow-up questions (follow_ups event)
+	DebugTrace          *PipelineTrace         `json:"debug_trace,omitempty"`          // Pipeline stage trace (debug_trace event)
 	SessionID           string                 `json:"session_id,omitempty"`           // Session ID (for agent_query event)
 	AssistantMessageID  string                 `json:"assistant_message_id,omitempty"` // Assistant Message ID (for agent_query event)
 	ToolCalls           []LLMToolCall          `json:"tool_calls,omitempty"`           // Tool calls for streaming (partial)
 	Data                map[string]interface{} `json:"data,omitempty"`                 // Additional metadata for enhanced display
 }
 
+// PipelineTrace records what each stage of a debug-traced knowledge QA run
+// read and produced
+type PipelineTrace struct {
+	Query            string                 `json:"query"`
+	Config           *PipelineConfig        `json:"config"` // Effective stage configuration of the run
+	KnowledgeBaseIDs []string               `json:"knowledge_base_ids,omitempty"`
+	KnowledgeIDs     []string               `json:"knowledge_ids,omitempty"`
+	ChatModelID      string                 `json:"chat_model_id,omitempty"`
+	RerankModelID    string                 `json:"rerank_model_id,omitempty"`
+	Stages           []PipelineStageTrace   `json:"stages"`
+	Exclusions       []ChunkExclusion       `json:"exclusions"`       // Retrieval candidates dropped along the way
+	Prompt           []PipelineTraceMessage `json:"prompt,omitempty"` // Final prompt sent to the answering model
+}
+
+// PipelineStageTrace records one pipeline stage
+type PipelineStageTrace struct {
+	Stage      string                 `json:"stage"`
+	DurationMs int64                  `json:"duration_ms"`
+	Error      string                 `json:"error,omitempty"`  // Error type of the stage, e.g. search_nothing
+	Output     map[string]interface{} `json:"output,omitempty"` // Stage-specific state, such as the rewritten query or results
+}
+
+// ChunkExclusion is a retrieval candidate dropped by a pipeline stage
+type ChunkExclusion struct {
+	ChunkID     string  `json:"chunk_id"`
+	KnowledgeID string  `json:"knowledge_id,omitempty"`
+	Stage       string  `json:"stage"`
+	Reason      string  `json:"reason"` // below_threshold, deduplicated, token_budget, ...
+	Score       float64 `json:"score,omitempty"`
+	Detail      string  `json:"detail,omitempty"`
+}
+
+// PipelineTraceMessage is one message of the traced prompt
+type PipelineTraceMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
 // KnowledgeQAStream knowledge Q&A streaming API
 func (c *Client) KnowledgeQAStream(
 	ctx context.Context,
@@ -433,6 +548,31 @@ func (c *Client) StopSession(ctx context.Context, sessionID string, messageID st
 	return parseResponse(resp, &response)
 }
 
+// EndSession ends a session. Under the "session_end" memory policy the
+// session is stored in the user's memory; it reports whether that was queued.
+func (c *Client) EndSession(ctx context.Context, sessionID string) (bool, error) {
+	if strings.TrimSpace(sessionID) == "" {
+		return false, fmt.Errorf("sessionID cannot be empty")
+	}
+
+	path := fmt.Sprintf("/api/v1/sessions/%s/end", sessionID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return false, err
+	}
+
+	var response struct {
+		Success bool `json:"success"`
+		Data    struct {
+			MemoryQueued bool `json:"memory_queued"`
+		} `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return false, err
+	}
+	return response.Data.MemoryQueued, nil
+}
+
 // SearchKnowledgeRequest knowledge search request
 type SearchKnowledgeRequest struct {
 	Query            string   `json:"query"`                        // Query content
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// SQLConnection represents a tenant database that knowledge QA may answer
+// analytical questions from with generated read-only SQL
+type SQLConnection struct {
+	ID            string                  `json:"id,omitempty"`
+	TenantID      uint64                  `json:"tenant_id,omitempty"`
+	Name          string                  `json:"name"`
+	Description   string                  `json:"description"`
+	Driver        string                  `json:"driver"` // postgres or mysql
+	Parameters    SQLConnectionParameters `json:"parameters"`
+	AllowedTables []string                `json:"allowed_tables"`
+	MaxRows       int                     `json:"max_rows"`
+	QueryTimeout  int                     `json:"query_timeout"`
+	CreatedAt     string                  `json:"created_at,omitempty"`
+	UpdatedAt     string                  `json:"updated_at,omitempty"`
+	// Credentials reports whether the password is configured; the password
+	// itself is never returned
+	Credentials map[string]SQLCredentialStatus `json:"credentials,omitempty"`
+}
+
+// SQLCredentialStatus reports whether a secret field is set
+type SQLCredentialStatus struct {
+	Configured bool `json:"configured"`
+}
+
+// SQLConnectionParameters holds where and how to connect. Password is
+// accepted on create and update (empty keeps the stored one) but never
+// returned.
+type SQLConnectionParameters struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"`
+	Database string `json:"database"`
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+	SSLMode  string `json:"ssl_mode,omitempty"`
+}
+
+// CreateSQLConnection creates a new SQL connection
+func (c *Client) CreateSQLConnection(ctx context.Context, conn *SQLConnection) (*SQLConnection, error) {
+	resp, err := c.doRequest(ctx, http.MethodPost, "/api/v1/sql-connections", conn, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Success bool           `json:"success"`
+		Data    *SQLConnection `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// ListSQLConnections lists the SQL connections of the current tenant
+func (c *Client) ListSQLConnections(ctx context.Context) ([]*SQLConnection, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/sql-connections", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Success bool             `json:"success"`
+		Data    []*SQLConnection `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// GetSQLConnection gets a SQL connection by ID
+func (c *Client) GetSQLConnection(ctx context.Context, id string) (*SQLConnection, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, fmt.Sprintf("/api/v1/sql-connections/%s", id), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Success bool           `json:"success"`
+		Data    *SQLConnection `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// UpdateSQLConnection replaces the settings of a SQL connection. The driver
+// cannot be changed.
+func (c *Client) UpdateSQLConnection(ctx context.Context, id string, conn *SQLConnection) (*SQLConnection, error) {
+	resp, err := c.doRequest(ctx, http.MethodPut, fmt.Sprintf("/api/v1/sql-connections/%s", id), conn, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Success bool           `json:"success"`
+		Data    *SQLConnection `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// DeleteSQLConnection deletes a SQL connection
+func (c *Client) DeleteSQLConnection(ctx context.Context, id string) error {
+	resp, err := c.doRequest(ctx, http.MethodDelete, fmt.Sprintf("/api/v1/sql-connections/%s", id), nil, nil)
+	if err != nil {
+		return err
+	}
+	return parseResponse(resp, nil)
+}
+
+// TestSQLConnection pings a saved SQL connection. A failed connection is
+// reported as an error.
+func (c *Client) TestSQLConnection(ctx context.Context, id string) error {
+	resp, err := c.doRequest(ctx, http.MethodPost, fmt.Sprintf("/api/v1/sql-connections/%s/test", id), nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return err
+	}
+	if !result.Success {
+		return fmt.Errorf("sql connection test failed: %s", result.Error)
+	}
+	return nil
+}
@@ -3,7 +3,9 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 )
 
 // SystemInfo represents system version and configuration information
@@ -61,6 +63,40 @@ type StorageCheckResponse struct {
 	BucketCreated bool   `json:"bucket_created,omitempty"`
 }
 
+// DependencyHealth is the status of one dependency of the server
+type DependencyHealth struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Critical  bool   `json:"critical"`
+	Message   string `json:"message,omitempty"`
+	// Collections is the load state of each Milvus collection
+	Collections []struct {
+		Collection string `json:"collection"`
+		Dimension  int    `json:"dimension"`
+		State      string `json:"state"`
+		Progress   int64  `json:"progress"`
+		Error      string `json:"error,omitempty"`
+	} `json:"collections,omitempty"`
+	// Model is set for model endpoints
+	Model *struct {
+		ID       string `json:"id"`
+		Type     string `json:"type"`
+		Source   string `json:"source"`
+		Endpoint string `json:"endpoint,omitempty"`
+	} `json:"model,omitempty"`
+}
+
+// SystemHealth is the health of the server and each of its dependencies.
+// Status is ok, degraded or down.
+type SystemHealth struct {
+	Status       string              `json:"status"`
+	Degraded     []string            `json:"degraded"`
+	CheckedAt    time.Time           `json:"checked_at"`
+	Dependencies []*DependencyHealth `json:"dependencies"`
+}
+
 // GetSystemInfo gets system version and configuration information
 func (c *Client) GetSystemInfo(ctx context.Context) (*SystemInfo, error) {
 	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/system/info", nil, nil)
@@ -151,3 +187,27 @@ func (c *Client) CheckStorageEngine(ctx context.Context, req *StorageCheckReques
 	}
 	return result.Data, nil
 }
+
+// GetSystemHealth probes the dependencies of the server. The report is also
+// returned when the server is down, which it answers with HTTP 503.
+func (c *Client) GetSystemHealth(ctx context.Context) (*SystemHealth, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/system/health", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result struct {
+		Success bool          `json:"success"`
+		Data    *SystemHealth `json:"data"`
+	}
+	if resp.StatusCode == http.StatusServiceUnavailable {
+		defer resp.Body.Close()
+		if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || result.Data == nil {
+			return nil, fmt.Errorf("HTTP error %d", resp.StatusCode)
+		}
+		return result.Data, nil
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
@@ -15,11 +15,15 @@ type Tag struct {
 	SeqID           int64     `json:"seq_id"`
 	TenantID        uint64    `json:"tenant_id"`
 	KnowledgeBaseID string    `json:"knowledge_base_id"`
+	ParentID        string    `json:"parent_id"`
 	Name            string    `json:"name"`
 	Color           string    `json:"color"`
 	SortOrder       int       `json:"sort_order"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
+	// Source is "manual" or "auto" when the tag is listed as one of a
+	// document's tags
+	Source string `json:"source,omitempty"`
 }
 
 // TagWithStats represents tag information along with usage statistics.
@@ -34,6 +38,8 @@ type CreateTagPayload struct {
 	Name      string `json:"name"`
 	Color     string `json:"color,omitempty"`
 	SortOrder int    `json:"sort_order,omitempty"`
+	// ParentID nests the new tag under a tag of the same knowledge base
+	ParentID string `json:"parent_id,omitempty"`
 }
 
 // UpdateTagPayload is used to update an existing tag.
@@ -41,6 +47,8 @@ type UpdateTagPayload struct {
 	Name      *string `json:"name,omitempty"`
 	Color     *string `json:"color,omitempty"`
 	SortOrder *int    `json:"sort_order,omitempty"`
+	// ParentID moves the tag; an empty string moves it to the top level
+	ParentID *string `json:"parent_id,omitempty"`
 }
 
 // TagsPage contains paginated tag results.
@@ -185,3 +193,110 @@ func (c *Client) DeleteTagBySeqID(ctx context.Context,
 ) error {
 	return c.DeleteTag(ctx, knowledgeBaseID, strconv.FormatInt(tagSeqID, 10), force, contentOnly, excludeIDs)
 }
+
+// RetagKnowledgeBase enqueues auto-tagging of the given documents of a
+// knowledge base, or of all its documents when knowledgeIDs is empty.
+// Documents whose tags were set manually are skipped.
+func (c *Client) RetagKnowledgeBase(ctx context.Context, knowledgeBaseID string, knowledgeIDs []string) error {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/auto-tag", knowledgeBaseID)
+	body := map[string]interface{}{
+		"knowledge_ids": knowledgeIDs,
+	}
+	resp, err := c.doRequest(ctx, http.MethodPost, path, body, nil)
+	if err != nil {
+		return err
+	}
+
+	var response tagSimpleResponse
+	return parseResponse(resp, &response)
+}
+
+// Tag access rule subject types.
+const (
+	TagAccessSubjectUser   = "user"
+	TagAccessSubjectRole   = "role"
+	TagAccessSubjectAPIKey = "api_key"
+)
+
+// TagAccessRule restricts what a subject retrieves from a knowledge base to
+// the content under some tag subtrees.
+type TagAccessRule struct {
+	ID              string    `json:"id"`
+	TenantID        uint64    `json:"tenant_id"`
+	KnowledgeBaseID string    `json:"knowledge_base_id"`
+	SubjectType     string    `json:"subject_type"`
+	SubjectID       string    `json:"subject_id"`
+	TagIDs          []string  `json:"tag_ids"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TagAccessRulePayload creates or replaces the rule of a subject.
+// SubjectID is a user ID for user rules, a tenant role for role rules and
+// ignored for api_key rules.
+type TagAccessRulePayload struct {
+	SubjectType string   `json:"subject_type"`
+	SubjectID   string   `json:"subject_id,omitempty"`
+	TagIDs      []string `json:"tag_ids"`
+}
+
+// TagAccessRulesResponse wraps the tag access rule list response.
+type TagAccessRulesResponse struct {
+	Success bool             `json:"success"`
+	Data    []*TagAccessRule `json:"data"`
+	Message string           `json:"message,omitempty"`
+	Code    string           `json:"code,omitempty"`
+}
+
+// TagAccessRuleResponse wraps a single tag access rule response.
+type TagAccessRuleResponse struct {
+	Success bool           `json:"success"`
+	Data    *TagAccessRule `json:"data"`
+	Message string         `json:"message,omitempty"`
+	Code    string         `json:"code,omitempty"`
+}
+
+// ListTagAccessRules returns the tag access rules of a knowledge base.
+func (c *Client) ListTagAccessRules(ctx context.Context, knowledgeBaseID string) ([]*TagAccessRule, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/tag-access-rules", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodGet, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response TagAccessRulesResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// SaveTagAccessRule creates the rule of a subject on a knowledge base, or
+// replaces the tags of its existing rule.
+func (c *Client) SaveTagAccessRule(ctx context.Context,
+	knowledgeBaseID string, payload *TagAccessRulePayload,
+) (*TagAccessRule, error) {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/tag-access-rules", knowledgeBaseID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, payload, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response TagAccessRuleResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// DeleteTagAccessRule deletes a tag access rule of a knowledge base.
+func (c *Client) DeleteTagAccessRule(ctx context.Context, knowledgeBaseID, ruleID string) error {
+	path := fmt.Sprintf("/api/v1/knowledge-bases/%s/tag-access-rules/%s", knowledgeBaseID, ruleID)
+	resp, err := c.doRequest(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	var response tagSimpleResponse
+	return parseResponse(resp, &response)
+}
@@ -34,6 +34,8 @@ type Tenant struct {
 	Description string `yaml:"description"       json:"description"`
 	// API key for authentication
 	APIKey string `yaml:"api_key"           json:"api_key"`
+	// Tenant role granted to requests authenticated with the API key
+	APIKeyRole string `yaml:"api_key_role"      json:"api_key_role,omitempty"`
 	// Tenant status (active, inactive)
 	Status string `yaml:"status"            json:"status"            gorm:"default:'active'"`
 	// Configured retrieval engines
@@ -127,6 +129,27 @@ func (c *Client) DeleteTenant(ctx context.Context, tenantID uint64) error {
 	return parseResponse(resp, &response)
 }
 
+// UpdateAPIKeyRole sets the tenant role (viewer, contributor or admin)
+// granted to requests authenticated with the tenant's API key
+func (c *Client) UpdateAPIKeyRole(ctx context.Context, tenantID uint64, role string) (string, error) {
+	path := fmt.Sprintf("/api/v1/tenants/%d/api-key/role", tenantID)
+	resp, err := c.doRequest(ctx, http.MethodPut, path, map[string]string{"role": role}, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var response struct {
+		Success bool `json:"success"`
+		Data    struct {
+			APIKeyRole string `json:"api_key_role"`
+		} `json:"data"`
+	}
+	if err := parseResponse(resp, &response); err != nil {
+		return "", err
+	}
+	return response.Data.APIKeyRole, nil
+}
+
 // ListTenants retrieves all tenants
 func (c *Client) ListTenants(ctx context.Context) ([]Tenant, error) {
 	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/tenants", nil, nil)
@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// TokenUsageSummary is the token usage of one group of a usage report.
+// Key is the day, model ID or session ID the group stands for.
+type TokenUsageSummary struct {
+	Key               string `json:"key"`
+	ModelName         string `json:"model_name,omitempty"`
+	Requests          int64  `json:"requests"`
+	EstimatedRequests int64  `json:"estimated_requests"`
+	PromptTokens      int64  `json:"prompt_tokens"`
+	CompletionTokens  int64  `json:"completion_tokens"`
+	EmbeddingTokens   int64  `json:"embedding_tokens"`
+	TotalTokens       int64  `json:"total_tokens"`
+}
+
+// TokenUsageReport is the token usage of the current tenant over a date range
+type TokenUsageReport struct {
+	StartDate string              `json:"start_date"`
+	EndDate   string              `json:"end_date"`
+	GroupBy   string              `json:"group_by"`
+	Total     TokenUsageSummary   `json:"total"`
+	Items     []TokenUsageSummary `json:"items"`
+}
+
+// TokenUsageQuery selects a usage report. Dates are UTC days formatted as
+// YYYY-MM-DD; empty fields use the server defaults (the last 30 days,
+// grouped by day).
+type TokenUsageQuery struct {
+	StartDate string
+	EndDate   string
+	GroupBy   string // day, model or session
+	ModelID   string
+	SessionID string
+}
+
+// TokenBudget limits the tokens the tenant may use per UTC day and month.
+// A zero limit is no limit.
+type TokenBudget struct {
+	DailyTokens   int64 `json:"daily_tokens"`
+	MonthlyTokens int64 `json:"monthly_tokens"`
+}
+
+// TokenBudgetStatus is the tenant's token budget and its current usage
+type TokenBudgetStatus struct {
+	Budget      TokenBudget `json:"budget"`
+	DailyUsed   int64       `json:"daily_used"`
+	MonthlyUsed int64       `json:"monthly_used"`
+	Exceeded    bool        `json:"exceeded"`
+}
+
+// GetTokenUsage returns the token usage report of the current tenant
+func (c *Client) GetTokenUsage(ctx context.Context, q TokenUsageQuery) (*TokenUsageReport, error) {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"start_date": q.StartDate,
+		"end_date":   q.EndDate,
+		"group_by":   q.GroupBy,
+		"model_id":   q.ModelID,
+		"session_id": q.SessionID,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/usage", nil, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Success bool              `json:"success"`
+		Data    *TokenUsageReport `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// GetTokenBudget returns the token budget of the current tenant
+func (c *Client) GetTokenBudget(ctx context.Context) (*TokenBudgetStatus, error) {
+	resp, err := c.doRequest(ctx, http.MethodGet, "/api/v1/usage/budget", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Success bool               `json:"success"`
+		Data    *TokenBudgetStatus `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// UpdateTokenBudget sets the token budget of the current tenant
+func (c *Client) UpdateTokenBudget(ctx context.Context, budget *TokenBudget) (*TokenBudgetStatus, error) {
+	resp, err := c.doRequest(ctx, http.MethodPut, "/api/v1/usage/budget", budget, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Success bool               `json:"success"`
+		Data    *TokenBudgetStatus `json:"data"`
+	}
+	if err := parseResponse(resp, &result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
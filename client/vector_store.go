@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// VectorStoreReindexReport summarizes a collection rebuild of a vector store
+type VectorStoreReindexReport struct {
+	EngineType  string                    `json:"engine_type"`
+	Collections []CollectionReindexResult `json:"collections"`
+}
+
+// CollectionReindexResult is the outcome for a single per-dimension collection.
+// Error is empty on success; a failed collection keeps its original data.
+type CollectionReindexResult struct {
+	Collection string `json:"collection"`
+	Dimension  int    `json:"dimension"`
+	Rows       int    `json:"rows"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Failed returns the number of collections that could not be rebuilt
+func (r *VectorStoreReindexReport) Failed() int {
+	if r == nil {
+		return 0
+	}
+	n := 0
+	for _, c := range r.Collections {
+		if c.Error != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// VectorStoreReindexResponse represents the API response for a reindex request
+type VectorStoreReindexResponse struct {
+	Success bool                     `json:"success"`
+	Data    VectorStoreReindexReport `json:"data"`
+}
+
+// ReindexVectorStore rebuilds every collection of a tenant-owned vector store
+// so that schema-level settings (analyzer, index parameters) take effect.
+// Per-collection failures are reported in the returned report, not as an error.
+func (c *Client) ReindexVectorStore(ctx context.Context, storeID string) (*VectorStoreReindexReport, error) {
+	if storeID == "" {
+		return nil, fmt.Errorf("vector store ID cannot be empty")
+	}
+	path := fmt.Sprintf("/api/v1/vector-stores/%s/reindex", storeID)
+	resp, err := c.doRequest(ctx, http.MethodPost, path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var response VectorStoreReindexResponse
+	if err := parseResponse(resp, &response); err != nil {
+		return nil, err
+	}
+
+	return &response.Data, nil
+}
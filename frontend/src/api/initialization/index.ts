@@ -329,6 +329,52 @@ export function testEmbeddingModel(modelConfig: {
 }
 
 
+// 单项探测结果：passed 通过；failed 调用失败；unsupported 调用成功但模型不具备该能力；skipped 未执行
+export interface ModelProbeCheck {
+    status: 'passed' | 'failed' | 'unsupported' | 'skipped';
+    latency_ms: number;
+    message?: string;
+}
+
+// 模型能力探测报告，只包含与模型类型相关的检查项
+export interface ModelProbeReport {
+    model_name: string;
+    model_type: 'KnowledgeQA' | 'Embedding';
+    available: boolean;
+    duration_ms: number;
+    chat?: ModelProbeCheck;
+    streaming?: ModelProbeCheck & { first_token_ms?: number; chunks: number };
+    tool_calls?: ModelProbeCheck;
+    context?: ModelProbeCheck & { tokens: number; source?: 'reported' | 'probed' };
+    embedding?: ModelProbeCheck & { dimension: number; configured_dimension?: number };
+}
+
+// 保存前探测模型能力：对话模型检查对话往返、流式输出、工具调用与上下文长度，
+// Embedding 模型探测向量维度。probeContext 会发送长提示词实测上下文长度，消耗较多 token。
+export function probeModel(modelConfig: {
+    type: 'KnowledgeQA' | 'Embedding';
+    source?: 'local' | 'remote';
+    modelName: string;
+    baseUrl?: string;
+    apiKey?: string;
+    provider?: string;
+    dimension?: number;
+    modelId?: string;
+    probeContext?: boolean;
+    maxContextTokens?: number;
+} & BaseModelTestPayload): Promise<ModelProbeReport> {
+    return new Promise((resolve, reject) => {
+        post('/api/v1/initialization/models/probe', modelConfig)
+            .then((response: any) => {
+                resolve(response.data || {});
+            })
+            .catch((error: any) => {
+                console.error('Failed to probe model:', error);
+                reject(error);
+            });
+    });
+}
+
 export function checkRerankModel(modelConfig: {
     modelName: string;
     baseUrl: string;
@@ -261,6 +261,10 @@ export function cancelKnowledgeParse(id: string) {
   return post(`/api/v1/knowledge/${id}/cancel-parse`);
 }
 
+export function regenerateKnowledgeQuestions(id: string) {
+  return post(`/api/v1/knowledge/${id}/questions/regenerate`);
+}
+
 export function getKnowledgeSpans(id: string, attempt?: number) {
   const qs = attempt ? `?attempt=${attempt}` : '';
   return get(`/api/v1/knowledge/${id}/spans${qs}`);
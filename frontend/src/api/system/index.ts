@@ -9,6 +9,8 @@ export interface SystemInfo {
   keyword_index_engine?: string
   vector_store_engine?: string
   graph_database_engine?: string
+  /** Where conversation memory is stored: "Neo4j", "Database" or "Not Enabled". */
+  memory_engine?: string
   minio_enabled?: boolean
   db_version?: string
   /** Human-readable error message when the startup migration failed.
@@ -99,6 +101,9 @@ export interface ParserEngineConfig {
   paddleocr_vl_cloud_model?: string
   paddleocr_vl_cloud_use_seal_recognition?: boolean | null
   paddleocr_vl_cloud_use_chart_recognition?: boolean | null
+  // 扫描件 OCR 兜底（PDF 无文本层、图片未开启多模态时调用）
+  ocr_endpoint?: string
+  ocr_provider?: string
 }
 
 export interface ParserEnginesResponse {
@@ -235,6 +240,45 @@ export function checkStorageEngine(req: StorageCheckRequest): Promise<{ data: St
   return post('/api/v1/system/storage-engine-check', req)
 }
 
+export type HealthStatus = 'ok' | 'degraded' | 'down' | 'disabled'
+
+export interface DependencyHealth {
+  name: string
+  kind: 'database' | 'cache' | 'vector_store' | 'graph_database' | 'object_storage' | 'model'
+  status: HealthStatus
+  latency_ms: number
+  critical: boolean
+  message?: string
+  /** Load state of each Milvus collection */
+  collections?: {
+    collection: string
+    dimension: number
+    state: 'loaded' | 'loading' | 'not_loaded'
+    progress: number
+    error?: string
+  }[]
+  /** Set for model endpoints; endpoint is empty for built-in models */
+  model?: {
+    id: string
+    type: string
+    source: string
+    endpoint?: string
+  }
+}
+
+export interface SystemHealth {
+  status: Exclude<HealthStatus, 'disabled'>
+  /** Names of the dependencies that are down or degraded */
+  degraded: string[]
+  checked_at: string
+  dependencies: DependencyHealth[]
+}
+
+/** Admin only. Answered with HTTP 503 when a critical dependency is down. */
+export function getSystemHealth(): Promise<{ data: SystemHealth }> {
+  return get('/api/v1/system/health')
+}
+
 // ---- System Admin Management ----
 
 export interface SystemAdminUser {
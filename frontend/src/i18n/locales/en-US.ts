@@ -547,6 +547,9 @@ export default {
     cancelParseConfirmBody: 'Stop parsing "{title}"? Already-written chunks are kept and can be re-parsed later via "Rebuild"; pending optimization tasks (summary / Q&A / knowledge graph) will be dropped immediately.',
     cancelParseSubmitted: 'Parsing stopped',
     cancelParseFailed: 'Failed to stop, please try again later',
+    regenerateQuestions: 'Regenerate questions',
+    regenerateQuestionsSubmitted: 'Regenerating questions for {count} chunks',
+    regenerateQuestionsFailed: 'Failed to regenerate questions, please try again later',
     draft: 'Draft',
     draftTip: 'Temporarily saved and not included in retrieval',
     untitledDocument: 'Untitled Document',
@@ -1192,7 +1195,7 @@ export default {
     enableMemoryDesc: 'When enabled, the system will record your conversation history and automatically recall relevant content in future conversations to provide more personalized answers.',
     autoCheckUpdate: 'Auto Download Updates',
     autoCheckUpdateDesc: 'When enabled, automatically check and download the latest version in the background.',
-    memoryRequiresNeo4j: 'Memory feature requires Neo4j graph database. Please configure and enable Neo4j (set NEO4J_ENABLE=true) before enabling this feature.',
+    memoryRequiresNeo4j: 'Memory feature requires a storage backend. Enable Neo4j (set NEO4J_ENABLE=true), or set MEMORY_DRIVER=database to store memory in the application database, before enabling this feature.',
     memoryHowToEnable: 'View Neo4j Configuration Guide',
     vectorStoreEngine: 'Vector DB Engine',
     parserEngine: 'Parser Engine',
@@ -2873,6 +2876,12 @@ export default {
       },
       parentChildLabel: 'Parent-Child Chunking',
       parentChildDescription: 'Two-level chunking: small child chunks are vector-matched (precise hits) but the larger parent chunk is returned to the LLM (richer context). Recommended for long documents (>10 pages); skip for short FAQs to save storage.',
+      tableChunkingLabel: 'Keep tables intact',
+      tableChunkingDescription: 'Converts document tables to Markdown and stores each as its own chunk with its columns and row range, so tables are never cut mid-row. Very long tables are split between rows.',
+      tableSummaryLabel: 'Summarize tables',
+      tableSummaryDescription: 'Uses the knowledge base summary model to describe each table; the description is indexed with the table so prose questions can find it.',
+      contextualRetrievalLabel: 'Contextual retrieval',
+      contextualRetrievalDescription: 'Uses the knowledge base summary model to write a 1–2 sentence context situating each chunk within its document, embedded together with the chunk so fragments that rely on context are still found. Adds parsing time and model calls.',
       parentChunkSizeLabel: 'Parent Chunk Size',
       parentChunkSizeDescription: 'Size of the context chunk returned to the LLM (512–8192). Default 4096 ≈ 1000 English tokens, fits comfortably in any modern LLM context window.',
       childChunkSizeLabel: 'Child Chunk Size',
@@ -2893,6 +2902,10 @@ export default {
           label: 'Structure-aware',
           tooltip: 'Splits on detected structural cues: page-breaks, numbered sections, multilingual chapter markers (DE/EN/ZH), all-caps titles. Ideal for PDFs without Markdown headings.'
         },
+        markdown: {
+          label: 'Markdown structure',
+          tooltip: 'Starts a chunk at every heading, records the full heading path (e.g. "Guide > Install > Linux") and prefixes it when embedding; code blocks are never split. Best for technical docs.'
+        },
         legacy: {
           label: 'Length-based',
           tooltip: 'Ignores structure; splits recursively by character count and separators — the original behavior. Use when the structure-aware strategies misbehave on your content.'
@@ -2965,6 +2978,9 @@ export default {
         description: 'Generate related questions for each chunk using LLM during document parsing to improve retrieval recall. Enabling this will increase document parsing time.',
         countLabel: 'Question Count',
         countDescription: 'Number of questions to generate per document chunk (1-10)',
+        modelLabel: 'Question Model',
+        modelDescription: "Chat model used to generate the questions. Leave empty to use the knowledge base's summary model",
+        modelPlaceholder: 'Defaults to the summary model',
       },
       multimodal: {
         label: 'Multimodal Feature',
@@ -3795,6 +3811,14 @@ export default {
           label: 'Azure OpenAI',
           description: 'OpenAI service hosted on Microsoft Azure',
         },
+        bedrock: {
+          label: 'Amazon Bedrock',
+          description: 'Models hosted on Amazon Bedrock (Claude, Nova, Titan, Cohere)',
+        },
+        self_hosted: {
+          label: 'Self-hosted (vLLM / llama.cpp)',
+          description: 'Self-hosted vLLM or llama.cpp servers, load-balanced across replicas with health checks',
+        },
         aliyun: {
           label: 'Aliyun DashScope',
           description: 'qwen-plus, tongyi-embedding-vision-plus, qwen3-rerank, etc.',
@@ -3819,6 +3843,10 @@ export default {
           label: 'Jina',
           description: 'jina-clip-v1, jina-embeddings-v2-base-zh, etc.',
         },
+        cohere: {
+          label: 'Cohere',
+          description: 'rerank-v3.5, rerank-multilingual-v3.0, etc.',
+        },
         volcengine: {
           label: 'Volcengine',
           description: 'doubao-1-5-pro-32k-250115, doubao-embedding-vision-250615, etc.',
@@ -4828,6 +4856,7 @@ export default {
     exitFullscreen: 'Exit Fullscreen',
     audioLoading: 'Loading audio…',
     audioNotSupported: 'Your browser does not support audio playback',
+    playFromHere: 'Play from here',
   },
   commandPalette: {
     placeholder: 'Search knowledge bases, files, conversations…',
@@ -5100,6 +5129,7 @@ export default {
       dataAnalysis: 'Data Analysis',
       dataSchema: 'Data Schema',
       databaseQuery: 'Database Query',
+      calculator: 'Calculator',
     },
     summary: {
       searchKb: 'Searched knowledge base <strong>{count}</strong> time(s)',
@@ -5271,6 +5301,8 @@ export default {
       thinkingDesc: 'Dynamic and reflective problem-solving thinking tool',
       todoWrite: 'Plan',
       todoWriteDesc: 'Create structured research plans',
+      calculator: 'Calculator',
+      calculatorDesc: 'Evaluate arithmetic expressions exactly',
       grepChunks: 'Keyword Search',
       grepChunksDesc: 'Quickly locate documents and chunks containing specific keywords',
       knowledgeSearch: 'Semantic Search',
@@ -5582,6 +5614,7 @@ export default {
     connected: 'Connected',
     connectionFailed: 'Connection failed',
     isRequired: 'is required',
+    mustBeNumber: 'must be a number',
     credentialsLabel: 'credentials',
     resourceHint: 'Select the spaces or folders to sync',
     untitled: 'Untitled',
@@ -5650,19 +5683,32 @@ export default {
     connector: {
       feishu: 'Feishu',
       notion: 'Notion',
+      confluence: 'Confluence',
       yuque: 'Yuque',
       rss: 'RSS / Atom Feed',
+      git: 'Git Repository',
+      bucket: 'Object Storage',
+      imap: 'Email (IMAP)',
     },
     connectorDesc: {
       feishu: 'Sync documents, spreadsheets and files from Feishu Wiki',
       notion: 'Sync pages and databases from Notion',
+      confluence: 'Sync pages from Confluence spaces, keeping the page hierarchy',
       yuque: 'Sync documents from Yuque knowledge bases',
       rss: 'Sync articles from RSS / Atom feeds',
+      git: 'Sync source code and Markdown documents from a Git repository',
+      bucket: 'Watch an S3 / TOS / COS bucket prefix, ingesting new and changed documents and removing deleted ones',
+      imap: 'Poll IMAP mail folders and ingest emails as Markdown with sender and date',
     },
     field: {
       appId: 'App ID',
       appSecret: 'App Secret',
       integrationToken: 'Integration Token',
+      notionTokenHint: 'Internal integration token, or the OAuth access token of a public integration',
+      siteUrl: 'Site URL',
+      email: 'Account email (optional)',
+      confluenceSiteUrlHint: 'Confluence Cloud: https://your-domain.atlassian.net/wiki; Data Center / Server: your site URL. For OAuth 2.0 tokens use https://api.atlassian.com/ex/confluence/<cloudId>',
+      confluenceEmailHint: 'Confluence Cloud: the email of the account that created the API token. Leave empty to send the token as a Bearer token (Data Center personal access token or OAuth 2.0 access token)',
       apiToken: 'API Token',
       baseUrl: 'Base URL (optional)',
       baseUrlHint: 'Leave empty to use the Yuque public cloud (https://www.yuque.com). For Yuque Enterprise or self-hosted deployments, enter your company domain (e.g. https://your-company.yuque.com).',
@@ -5670,6 +5716,52 @@ export default {
       feedUrlsHint: 'One RSS / Atom feed URL per line; multiple feeds are supported.',
       authHeaders: 'Custom headers (optional)',
       authHeadersHint: 'For private feeds. One per line in "Name: Value" form, e.g. Authorization: Bearer xxxx',
+      repository: 'Repository',
+      repoUrl: 'Repository URL',
+      repoUrlHint: 'HTTP(S) clone URL, e.g. https://github.com/owner/repo.git. For private repositories, enter an access token below instead of putting it in the URL',
+      branch: 'Branch (optional)',
+      branchHint: 'Leave empty to sync the default branch',
+      accessToken: 'Access Token (optional)',
+      gitTokenHint: 'Required for private repositories, e.g. a GitHub / GitLab / Gitea personal access token with read-only repository access',
+      username: 'Username (optional)',
+      webhookSecret: 'Webhook Secret (optional)',
+      webhookSecretHint: 'Set it to add a push webhook on your Git host so that every push triggers a sync',
+      webhookUrl: 'Webhook URL',
+      webhookUrlHint: 'Add this URL as a push webhook in the GitHub / GitLab / Gitea repository settings, with the webhook secret above',
+      accessKeyId: 'Access Key ID',
+      secretAccessKey: 'Secret Access Key',
+      bucketWebhookSecretHint: 'Set it to send bucket event notifications so that object changes trigger a sync right away',
+      bucketLocation: 'Bucket',
+      provider: 'Provider',
+      region: 'Region',
+      regionHint: 'e.g. cn-beijing (TOS), ap-guangzhou (COS), us-east-1 (S3). Required for the default TOS / COS endpoint',
+      endpoint: 'Endpoint (optional)',
+      endpointHint: 'Leave empty to use the provider endpoint of the region. For MinIO or other self-hosted S3-compatible services, enter the full URL',
+      bucket: 'Bucket name',
+      prefix: 'Prefix (optional)',
+      prefixHint: 'Only objects under this prefix are synced, e.g. inbox/. Leave empty to sync the whole bucket',
+      forcePathStyle: 'Use path-style addressing (endpoint/bucket)',
+      bucketWebhookUrlHint: 'Send bucket event notifications to this URL (directly or through a cloud function) with the header Authorization: Bearer <webhook secret>',
+      imapServer: 'Mail server',
+      imapHost: 'IMAP server',
+      security: 'Connection security',
+      port: 'Port (optional)',
+      portHint: 'Leave empty to use 993 for SSL/TLS or 143 for STARTTLS / unencrypted',
+      sinceDays: 'Initial sync range in days (optional)',
+      sinceDaysHint: 'Only emails received in the last N days are imported on the first sync. Leave empty to import the whole folder',
+      imapUsername: 'Email address / username',
+      password: 'Password',
+      imapPasswordHint: 'Many providers (Gmail, QQ Mail, 163, Outlook) require an app password or authorization code instead of the login password',
+    },
+    provider: {
+      s3: 'AWS S3 / S3-compatible',
+      tos: 'Volcengine TOS',
+      cos: 'Tencent Cloud COS',
+    },
+    imapSecurity: {
+      tls: 'SSL/TLS',
+      starttls: 'STARTTLS',
+      none: 'None (unencrypted)',
     },
     comingSoon: 'Coming soon',
     docHint: 'Get credentials at:',
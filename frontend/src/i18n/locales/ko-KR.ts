@@ -487,6 +487,9 @@ export default {
     cancelParseConfirmBody: '"{title}"의 파싱을 중지하시겠습니까? 이미 저장된 청크는 유지되며 "다시 빌드"를 통해 다시 파싱할 수 있습니다. 최적화 단계(요약 / Q&A / 지식 그래프)의 대기 중인 작업은 즉시 취소됩니다.',
     cancelParseSubmitted: "파싱이 중지되었습니다",
     cancelParseFailed: "중지에 실패했습니다. 나중에 다시 시도해주세요",
+    regenerateQuestions: "질문 다시 생성",
+    regenerateQuestionsSubmitted: "{count}개 청크의 질문 재생성 작업을 제출했습니다",
+    regenerateQuestionsFailed: "질문 재생성에 실패했습니다. 나중에 다시 시도해주세요",
     characters: "자",
     segment: "조각",
     chunkCount: "총 {count}개 조각",
@@ -1042,7 +1045,7 @@ export default {
     enableMemoryDesc: "활성화하면 시스템이 대화 기록을 저장하고 향후 대화에서 관련 내용을 자동으로 회상하여 더 개인화된 답변을 제공합니다.",
     autoCheckUpdate: '업데이트 자동 다운로드',
     autoCheckUpdateDesc: '활성화하면 시작 시 최신 버전을 자동으로 확인하고 백그라운드에서 다운로드합니다.',
-    memoryRequiresNeo4j: "기억 기능은 Neo4j 그래프 데이터베이스가 필요합니다. 이 기능을 활성화하기 전에 Neo4j를 구성하고 활성화해 주세요 (NEO4J_ENABLE=true 설정).",
+    memoryRequiresNeo4j: "기억 기능에는 저장소 백엔드가 필요합니다. 이 기능을 활성화하기 전에 Neo4j를 활성화하거나 (NEO4J_ENABLE=true 설정) MEMORY_DRIVER=database를 설정하여 애플리케이션 데이터베이스에 기억을 저장하세요.",
     memoryHowToEnable: "Neo4j 구성 가이드 보기",
     vectorStoreEngine: "벡터 DB 엔진",
     parserEngine: "파싱 엔진",
@@ -2789,6 +2792,14 @@ export default {
           label: 'Azure OpenAI',
           description: 'Microsoft Azure에서 호스팅되는 OpenAI 서비스',
         },
+        bedrock: {
+          label: 'Amazon Bedrock',
+          description: 'Amazon Bedrock에서 호스팅되는 모델 (Claude, Nova, Titan, Cohere)',
+        },
+        self_hosted: {
+          label: '자체 호스팅 (vLLM / llama.cpp)',
+          description: '자체 호스팅 vLLM 또는 llama.cpp 추론 서버, 여러 복제본 간 부하 분산 및 상태 확인 지원',
+        },
         aliyun: {
           label: "Aliyun DashScope",
           description: "qwen-plus, tongyi-embedding-vision-plus, qwen3-rerank 등",
@@ -2813,6 +2824,10 @@ export default {
           label: "Jina",
           description: "jina-clip-v1, jina-embeddings-v2-base-zh, etc.",
         },
+        cohere: {
+          label: "Cohere",
+          description: "rerank-v3.5, rerank-multilingual-v3.0, etc.",
+        },
         volcengine: {
           label: "Volcengine",
           description: "doubao-1-5-pro-32k-250115, doubao-embedding-vision-250615 등",
@@ -3758,6 +3773,12 @@ export default {
       },
       parentChildLabel: "부모-자식 청킹",
       parentChildDescription: "2단계 청킹: 작은 자식 청크는 벡터 매칭(정확한 히트), 큰 부모 청크는 LLM에 반환(풍부한 컨텍스트). 긴 문서(>10페이지)에 권장; 짧은 FAQ는 비활성화하여 저장 공간 절약.",
+      tableChunkingLabel: "표 단위 청킹",
+      tableChunkingDescription: "문서의 표를 Markdown으로 변환해 열 이름과 행 범위와 함께 독립된 청크로 저장하여 표가 잘리지 않도록 합니다. 매우 긴 표는 행 단위로 분할됩니다.",
+      tableSummaryLabel: "표 요약 생성",
+      tableSummaryDescription: "지식베이스 요약 모델로 각 표의 간단한 설명을 생성해 표와 함께 색인하여 자연어 질문으로도 표를 찾을 수 있게 합니다.",
+      contextualRetrievalLabel: "청크 컨텍스트 생성",
+      contextualRetrievalDescription: "지식베이스의 요약 모델로 각 청크가 문서에서 어떤 위치와 주제인지 설명하는 1–2문장의 컨텍스트를 생성하고 청크와 함께 임베딩하여, 문맥에 의존하는 조각도 검색되도록 합니다. 파싱 시간과 모델 호출이 늘어납니다.",
       parentChunkSizeLabel: "부모 청크 크기",
       parentChunkSizeDescription: "LLM에 반환되는 컨텍스트 청크 크기 (512-8192). 기본값 4096 ≈ 1000 영어 토큰, 모든 현대 LLM 컨텍스트에 적합.",
       childChunkSizeLabel: "자식 청크 크기",
@@ -3778,6 +3799,10 @@ export default {
           label: "구조 인식",
           tooltip: "페이지 구분자, 번호 매겨진 섹션, 다국어 챕터 마커(DE/EN/ZH), 대문자 제목 등 구조적 신호를 감지하여 분할합니다. Markdown 헤딩이 없는 PDF/스캔본에 적합합니다."
         },
+        markdown: {
+          label: "Markdown 구조",
+          tooltip: "모든 제목에서 청크를 나누고 전체 제목 경로(예: \"가이드 > 설치 > Linux\")를 기록해 임베딩 시 앞에 붙입니다. 코드 블록은 절대 분할하지 않습니다. 기술 문서에 적합합니다."
+        },
         legacy: {
           label: "길이 기준",
           tooltip: "구조를 무시하고 문자 수와 구분자로만 재귀 분할합니다 — 원래 동작. 위 전략들이 콘텐츠에서 잘못 작동할 때 사용하세요."
@@ -3851,6 +3876,9 @@ export default {
           "문서 파싱 시 대규모 모델을 호출하여 각 청크에 대한 관련 질문을 생성하여 검색 재현율을 향상시킵니다. 활성화하면 문서 파싱 시간이 증가합니다.",
         countLabel: "생성 질문 수",
         countDescription: "각 문서 청크에서 생성할 질문 수 (1-10)",
+        modelLabel: "질문 생성 모델",
+        modelDescription: "질문 생성에 사용할 대화 모델입니다. 비워 두면 지식베이스의 요약 모델을 사용합니다",
+        modelPlaceholder: "기본값: 요약 모델",
       },
       multimodal: {
         label: "멀티모달 기능",
@@ -4845,6 +4873,7 @@ export default {
     exitFullscreen: '전체 화면 종료',
     audioLoading: '오디오 로딩 중…',
     audioNotSupported: '브라우저가 오디오 재생을 지원하지 않습니다',
+    playFromHere: '여기서부터 재생',
   },
   commandPalette: {
     placeholder: '지식베이스, 파일, 대화 검색…',
@@ -5117,6 +5146,7 @@ export default {
       dataAnalysis: '데이터 분석',
       dataSchema: '데이터 구조',
       databaseQuery: '데이터베이스 조회',
+      calculator: '계산기',
     },
     summary: {
       searchKb: '지식베이스 <strong>{count}</strong>회 검색',
@@ -5363,6 +5393,8 @@ export default {
       thinkingDesc: '동적이고 반성적인 문제 해결 사고 도구',
       todoWrite: '계획 수립',
       todoWriteDesc: '구조화된 연구 계획 생성',
+      calculator: '계산기',
+      calculatorDesc: '산술 표현식을 정확하게 계산',
       grepChunks: '키워드 검색',
       grepChunksDesc: '특정 키워드를 포함하는 문서와 청크를 빠르게 찾기',
       knowledgeSearch: '의미 검색',
@@ -5594,6 +5626,7 @@ export default {
     connected: "연결됨",
     connectionFailed: "연결 실패",
     isRequired: "은(는) 필수입니다",
+    mustBeNumber: "은(는) 숫자여야 합니다",
     credentialsLabel: "자격 증명",
     resourceHint: "동기화할 공간/폴더를 선택하세요",
     untitled: "제목 없음",
@@ -5662,19 +5695,32 @@ export default {
     connector: {
       feishu: "페이슈 (Feishu)",
       notion: "Notion",
+      confluence: "Confluence",
       yuque: "위큐 (Yuque)",
       rss: "RSS / Atom 피드",
+      git: "Git 저장소",
+      bucket: "오브젝트 스토리지",
+      imap: "이메일 (IMAP)",
     },
     connectorDesc: {
       feishu: "페이슈 위키에서 문서, 스프레드시트, 파일 동기화",
       notion: "Notion에서 페이지 및 데이터베이스 동기화",
+      confluence: "Confluence 스페이스의 페이지를 페이지 계층과 함께 동기화",
       yuque: "위큐 지식베이스에서 문서 동기화",
       rss: "RSS / Atom 피드에서 글 동기화",
+      git: "Git 저장소의 소스 코드와 Markdown 문서 동기화",
+      bucket: "S3 / TOS / COS 버킷 접두사를 감시하여 새 문서와 변경된 문서를 가져오고 삭제된 객체를 제거",
+      imap: "IMAP 메일 폴더를 주기적으로 가져와 보낸 사람과 날짜가 포함된 Markdown으로 가져오기",
     },
     field: {
       appId: "App ID",
       appSecret: "App Secret",
       integrationToken: "Integration Token",
+      notionTokenHint: "내부 통합 토큰 또는 공개 통합의 OAuth 액세스 토큰",
+      siteUrl: "사이트 URL",
+      email: "계정 이메일 (선택)",
+      confluenceSiteUrlHint: "Confluence Cloud: https://your-domain.atlassian.net/wiki, Data Center / Server: 사이트 URL. OAuth 2.0 토큰은 https://api.atlassian.com/ex/confluence/<cloudId> 사용",
+      confluenceEmailHint: "Confluence Cloud: API 토큰을 만든 계정의 이메일. 비워 두면 토큰을 Bearer 토큰으로 전송합니다 (Data Center 개인 액세스 토큰 또는 OAuth 2.0 액세스 토큰)",
       baseUrl: "Base URL",
       apiToken: "API Token",
       baseUrlHint: "비워두면 Yuque 퍼블릭 클라우드 https://www.yuque.com 를 사용합니다. Yuque Enterprise 또는 사설 배포를 사용하는 경우 기업 도메인(예: https://your-company.yuque.com)을 입력하세요",
@@ -5682,6 +5728,52 @@ export default {
       feedUrlsHint: "한 줄에 하나씩 RSS / Atom 피드 주소를 입력하세요. 여러 개를 함께 입력할 수 있습니다.",
       authHeaders: "사용자 지정 헤더 (선택)",
       authHeadersHint: "비공개 피드 접근용. 한 줄에 하나씩 「이름: 값」 형식으로 입력하세요. 예: Authorization: Bearer xxxx",
+      repository: "저장소",
+      repoUrl: "저장소 URL",
+      repoUrlHint: "HTTP(S) 클론 URL(예: https://github.com/owner/repo.git). 비공개 저장소는 URL에 넣지 말고 아래에 액세스 토큰을 입력하세요",
+      branch: "브랜치(선택)",
+      branchHint: "비워 두면 기본 브랜치를 동기화합니다",
+      accessToken: "액세스 토큰(선택)",
+      gitTokenHint: "비공개 저장소에 필요합니다. 저장소 읽기 권한만 있는 GitHub / GitLab / Gitea 개인 액세스 토큰 등",
+      username: "사용자 이름(선택)",
+      webhookSecret: "Webhook 시크릿(선택)",
+      webhookSecretHint: "설정하면 Git 호스팅에 Push Webhook을 추가하여 푸시할 때마다 동기화할 수 있습니다",
+      webhookUrl: "Webhook URL",
+      webhookUrlHint: "GitHub / GitLab / Gitea 저장소 설정에서 이 URL을 Push 이벤트 Webhook으로 추가하고 위의 Webhook 시크릿을 입력하세요",
+      accessKeyId: "Access Key ID",
+      secretAccessKey: "Secret Access Key",
+      bucketWebhookSecretHint: "설정하면 버킷 이벤트 알림을 보내 객체가 변경될 때 즉시 동기화할 수 있습니다",
+      bucketLocation: "버킷",
+      provider: "서비스 제공자",
+      region: "리전",
+      regionHint: "예: cn-beijing(TOS), ap-guangzhou(COS), us-east-1(S3). TOS / COS 기본 엔드포인트 사용 시 필수",
+      endpoint: "엔드포인트 (선택)",
+      endpointHint: "비워 두면 제공자와 리전의 기본 엔드포인트를 사용합니다. MinIO 등 자체 호스팅 S3 호환 서비스는 전체 URL을 입력하세요",
+      bucket: "버킷 이름",
+      prefix: "접두사 (선택)",
+      prefixHint: "이 접두사 아래의 객체만 동기화합니다(예: inbox/). 비워 두면 버킷 전체를 동기화합니다",
+      forcePathStyle: "경로 스타일 주소 사용 (endpoint/bucket)",
+      bucketWebhookUrlHint: "버킷 이벤트 알림을 이 URL로 보내세요(직접 또는 클라우드 함수를 통해). 요청 헤더에 Authorization: Bearer <Webhook 시크릿>을 포함해야 합니다",
+      imapServer: "메일 서버",
+      imapHost: "IMAP 서버",
+      security: "연결 보안",
+      port: "포트 (선택)",
+      portHint: "비워 두면 SSL/TLS는 993, STARTTLS / 암호화 없음은 143을 사용합니다",
+      sinceDays: "초기 동기화 범위(일, 선택)",
+      sinceDaysHint: "첫 동기화에서 최근 N일 동안 받은 메일만 가져옵니다. 비워 두면 폴더 전체를 가져옵니다",
+      imapUsername: "이메일 주소 / 사용자 이름",
+      password: "비밀번호",
+      imapPasswordHint: "Gmail, Outlook 등 많은 서비스는 로그인 비밀번호 대신 앱 비밀번호를 요구합니다",
+    },
+    provider: {
+      s3: "AWS S3 / S3 호환",
+      tos: "Volcengine TOS",
+      cos: "Tencent Cloud COS",
+    },
+    imapSecurity: {
+      tls: "SSL/TLS",
+      starttls: "STARTTLS",
+      none: "암호화 없음",
     },
     comingSoon: "곧 지원 예정",
     docHint: "다음에서 인증 정보를 받으세요:",
@@ -643,7 +643,10 @@ export default {
     cancelParse: 'Остановить разбор',
     cancelParseConfirmBody: 'Остановить разбор «{title}»? Уже записанные фрагменты сохранятся, и их можно будет разобрать заново через «Пересобрать». Ожидающие задачи оптимизации (резюме / вопросы и ответы / граф знаний) будут немедленно отменены.',
     cancelParseSubmitted: 'Разбор остановлен',
-    cancelParseFailed: 'Не удалось остановить, попробуйте позже'
+    cancelParseFailed: 'Не удалось остановить, попробуйте позже',
+    regenerateQuestions: 'Сгенерировать вопросы заново',
+    regenerateQuestionsSubmitted: 'Запущена повторная генерация вопросов для {count} фрагментов',
+    regenerateQuestionsFailed: 'Не удалось сгенерировать вопросы заново, попробуйте позже'
   },
   uploadConfirm: {
     title: 'Подтверждение загрузки',
@@ -1103,7 +1106,7 @@ export default {
     enableMemoryDesc: 'При включении система будет записывать историю ваших разговоров и автоматически вспоминать соответствующий контент в будущих беседах для более персонализированных ответов.',
     autoCheckUpdate: 'Автоматическая загрузка обновлений',
     autoCheckUpdateDesc: 'При включении автоматически проверять и скачивать последнюю версию в фоновом режиме при запуске.',
-    memoryRequiresNeo4j: 'Функция памяти требует графовую базу данных Neo4j. Пожалуйста, настройте и включите Neo4j (установите NEO4J_ENABLE=true) перед активацией этой функции.',
+    memoryRequiresNeo4j: 'Функции памяти нужно хранилище. Перед активацией этой функции включите Neo4j (установите NEO4J_ENABLE=true) или установите MEMORY_DRIVER=database, чтобы хранить память в базе данных приложения.',
     memoryHowToEnable: 'Руководство по настройке Neo4j',
     vectorStoreEngine: 'Движок векторной БД',
     parserEngine: 'Движок парсинга',
@@ -2502,6 +2505,14 @@ export default {
           label: 'Azure OpenAI',
           description: 'Сервис OpenAI на платформе Microsoft Azure',
         },
+        bedrock: {
+          label: 'Amazon Bedrock',
+          description: 'Модели на платформе Amazon Bedrock (Claude, Nova, Titan, Cohere)',
+        },
+        self_hosted: {
+          label: 'Собственный сервер (vLLM / llama.cpp)',
+          description: 'Собственные серверы vLLM или llama.cpp с балансировкой нагрузки между репликами и проверкой состояния',
+        },
         aliyun: {
           label: 'Aliyun DashScope',
           description: 'qwen-plus, tongyi-embedding-vision-plus, qwen3-rerank, etc.'
@@ -2526,6 +2537,10 @@ export default {
           label: 'Jina',
           description: 'jina-clip-v1, jina-embeddings-v2-base-zh, etc.'
         },
+        cohere: {
+          label: 'Cohere',
+          description: 'rerank-v3.5, rerank-multilingual-v3.0, etc.'
+        },
         volcengine: {
           label: 'Volcengine',
           description: 'doubao-1-5-pro-32k-250115, doubao-embedding-vision-250615, etc.'
@@ -3240,6 +3255,12 @@ export default {
       },
       parentChildLabel: 'Родительско-дочернее разбиение',
       parentChildDescription: 'Двухуровневое разбиение: маленькие дочерние блоки используются для векторного матчинга (точные совпадения), большой родительский блок возвращается LLM (более богатый контекст). Рекомендуется для длинных документов (>10 страниц); пропустите для коротких FAQ для экономии хранилища.',
+      tableChunkingLabel: 'Таблицы целиком',
+      tableChunkingDescription: 'Преобразует таблицы документа в Markdown и сохраняет каждую как отдельный блок с названиями столбцов и диапазоном строк, чтобы таблицы не разрезались. Очень длинные таблицы делятся по строкам.',
+      tableSummaryLabel: 'Описание таблиц',
+      tableSummaryDescription: 'Модель суммаризации базы знаний кратко описывает каждую таблицу; описание индексируется вместе с таблицей, чтобы её находили вопросы на естественном языке.',
+      contextualRetrievalLabel: 'Контекстный поиск',
+      contextualRetrievalDescription: 'Модель суммаризации базы знаний пишет для каждого фрагмента 1–2 предложения о его месте и теме в документе; они векторизуются вместе с фрагментом, чтобы находились и фрагменты, зависящие от контекста. Увеличивает время разбора и число вызовов модели.',
       parentChunkSizeLabel: 'Размер родительского блока',
       parentChunkSizeDescription: 'Размер контекстного блока, возвращаемого LLM (512–8192). По умолчанию 4096 ≈ 1000 английских токенов, комфортно вписывается в любое современное контекстное окно.',
       childChunkSizeLabel: 'Размер дочернего блока',
@@ -3260,6 +3281,10 @@ export default {
           label: 'По структуре',
           tooltip: 'Разбивает по обнаруженным структурным признакам: разрывы страниц, нумерованные разделы, многоязычные маркеры глав (DE/EN/ZH), заголовки в верхнем регистре. Идеально для PDF без заголовков Markdown.'
         },
+        markdown: {
+          label: 'Структура Markdown',
+          tooltip: 'Начинает блок с каждого заголовка, сохраняет полный путь заголовков (например, «Guide > Install > Linux») и добавляет его при векторизации; блоки кода никогда не разрезаются. Для технической документации.'
+        },
         legacy: {
           label: 'По длине',
           tooltip: 'Игнорирует структуру и разбивает рекурсивно по числу символов и разделителям — оригинальное поведение. Используйте, если стратегии с учётом структуры работают некорректно.'
@@ -3331,7 +3356,10 @@ export default {
         label: 'AI генерация вопросов',
         description: 'Генерация связанных вопросов для каждого фрагмента с помощью LLM при парсинге документа для улучшения полноты поиска. Включение увеличит время парсинга документа.',
         countLabel: 'Количество вопросов',
-        countDescription: 'Количество вопросов для генерации на фрагмент документа (1-10)'
+        countDescription: 'Количество вопросов для генерации на фрагмент документа (1-10)',
+        modelLabel: 'Модель для вопросов',
+        modelDescription: 'Чат-модель для генерации вопросов. Если не выбрана, используется модель резюме базы знаний',
+        modelPlaceholder: 'По умолчанию — модель резюме'
       },
       multimodal: {
         label: 'Мультимодальная функция',
@@ -4346,6 +4374,7 @@ export default {
     exitFullscreen: 'Выйти из полноэкранного режима',
     audioLoading: 'Загрузка аудио…',
     audioNotSupported: 'Ваш браузер не поддерживает воспроизведение аудио',
+    playFromHere: 'Воспроизвести с этого места',
   },
   commandPalette: {
     placeholder: 'Поиск по базам знаний, файлам, диалогам…',
@@ -4616,7 +4645,8 @@ export default {
       executeSkillScript: 'Выполнение скрипта навыка',
       dataAnalysis: 'Анализ данных',
       dataSchema: 'Структура данных',
-      databaseQuery: 'Запрос к базе данных'
+      databaseQuery: 'Запрос к базе данных',
+      calculator: 'Калькулятор'
     },
     summary: {
       searchKb: 'Поиск по базе знаний <strong>{count}</strong> раз(а)',
@@ -4782,6 +4812,8 @@ export default {
       thinkingDesc: 'Динамический инструмент рефлексивного решения проблем',
       todoWrite: 'Планирование',
       todoWriteDesc: 'Создание структурированных исследовательских планов',
+      calculator: 'Калькулятор',
+      calculatorDesc: 'Точное вычисление арифметических выражений',
       grepChunks: 'Поиск по ключевым словам',
       grepChunksDesc: 'Быстрый поиск документов и фрагментов с определёнными ключевыми словами',
       knowledgeSearch: 'Семантический поиск',
@@ -5416,6 +5448,7 @@ export default {
     connected: 'Подключено',
     connectionFailed: 'Подключение не удалось',
     isRequired: 'обязательно для заполнения',
+    mustBeNumber: 'должно быть числом',
     credentialsLabel: 'учётные данные',
     resourceHint: 'Выберите пространства или папки для синхронизации',
     untitled: 'Без названия',
@@ -5484,19 +5517,32 @@ export default {
     connector: {
       feishu: 'Feishu (Фэйшу)',
       notion: 'Notion',
+      confluence: 'Confluence',
       yuque: 'Yuque (Юйцюэ)',
       rss: 'RSS / Atom лента',
+      git: 'Git-репозиторий',
+      bucket: 'Объектное хранилище',
+      imap: 'Электронная почта (IMAP)',
     },
     connectorDesc: {
       feishu: 'Синхронизация документов, таблиц и файлов из Feishu Wiki',
       notion: 'Синхронизация страниц и баз данных из Notion',
+      confluence: 'Синхронизация страниц из пространств Confluence с сохранением иерархии',
       yuque: 'Синхронизация документов из баз знаний Yuque',
       rss: 'Синхронизация статей из лент RSS / Atom',
+      git: 'Синхронизация исходного кода и документов Markdown из Git-репозитория',
+      bucket: 'Отслеживание префикса бакета S3 / TOS / COS: загрузка новых и изменённых документов и удаление удалённых',
+      imap: 'Периодически загружает папки IMAP и импортирует письма в Markdown с отправителем и датой',
     },
     field: {
       appId: 'App ID',
       appSecret: 'App Secret',
       integrationToken: 'Integration Token',
+      notionTokenHint: 'Токен внутренней интеграции или токен доступа OAuth публичной интеграции',
+      siteUrl: 'URL сайта',
+      email: 'Email учётной записи (необязательно)',
+      confluenceSiteUrlHint: 'Confluence Cloud: https://your-domain.atlassian.net/wiki; Data Center / Server: URL вашего сайта. Для токенов OAuth 2.0: https://api.atlassian.com/ex/confluence/<cloudId>',
+      confluenceEmailHint: 'Confluence Cloud: email учётной записи, создавшей API-токен. Оставьте пустым, чтобы передавать токен как Bearer (персональный токен Data Center или токен доступа OAuth 2.0)',
       baseUrl: 'Base URL',
       apiToken: 'API Token',
       baseUrlHint: 'Оставьте пустым, чтобы использовать публичное облако Yuque https://www.yuque.com. Если вы используете Yuque Enterprise или приватное развёртывание, укажите корпоративный домен (например, https://your-company.yuque.com)',
@@ -5504,6 +5550,52 @@ export default {
       feedUrlsHint: 'По одному адресу ленты RSS / Atom в строке; можно указать несколько.',
       authHeaders: 'Пользовательские заголовки (необязательно)',
       authHeadersHint: 'Для приватных лент. По одному в строке в формате «Имя: Значение», например Authorization: Bearer xxxx',
+      repository: 'Репозиторий',
+      repoUrl: 'URL репозитория',
+      repoUrlHint: 'HTTP(S) URL для клонирования, например https://github.com/owner/repo.git. Для приватных репозиториев укажите токен доступа ниже, а не в URL',
+      branch: 'Ветка (необязательно)',
+      branchHint: 'Оставьте пустым, чтобы синхронизировать ветку по умолчанию',
+      accessToken: 'Токен доступа (необязательно)',
+      gitTokenHint: 'Нужен для приватных репозиториев, например персональный токен GitHub / GitLab / Gitea с доступом только на чтение',
+      username: 'Имя пользователя (необязательно)',
+      webhookSecret: 'Секрет webhook (необязательно)',
+      webhookSecretHint: 'Позволяет добавить push-webhook на Git-хостинге, чтобы каждый push запускал синхронизацию',
+      webhookUrl: 'URL webhook',
+      webhookUrlHint: 'Добавьте этот URL как webhook для push-событий в настройках репозитория GitHub / GitLab / Gitea и укажите секрет webhook выше',
+      accessKeyId: 'Access Key ID',
+      secretAccessKey: 'Secret Access Key',
+      bucketWebhookSecretHint: 'Укажите, чтобы отправлять уведомления о событиях бакета и запускать синхронизацию сразу при изменении объектов',
+      bucketLocation: 'Бакет',
+      provider: 'Провайдер',
+      region: 'Регион',
+      regionHint: 'Например, cn-beijing (TOS), ap-guangzhou (COS), us-east-1 (S3). Обязателен для стандартного endpoint TOS / COS',
+      endpoint: 'Endpoint (необязательно)',
+      endpointHint: 'Оставьте пустым, чтобы использовать endpoint провайдера для региона. Для MinIO и других S3-совместимых сервисов укажите полный URL',
+      bucket: 'Имя бакета',
+      prefix: 'Префикс (необязательно)',
+      prefixHint: 'Синхронизируются только объекты с этим префиксом, например inbox/. Оставьте пустым для всего бакета',
+      forcePathStyle: 'Адресация в стиле пути (endpoint/bucket)',
+      bucketWebhookUrlHint: 'Отправляйте уведомления о событиях бакета на этот URL (напрямую или через облачную функцию) с заголовком Authorization: Bearer <секрет webhook>',
+      imapServer: 'Почтовый сервер',
+      imapHost: 'Сервер IMAP',
+      security: 'Защита соединения',
+      port: 'Порт (необязательно)',
+      portHint: 'Оставьте пустым, чтобы использовать 993 для SSL/TLS или 143 для STARTTLS / без шифрования',
+      sinceDays: 'Период первой синхронизации, дней (необязательно)',
+      sinceDaysHint: 'При первой синхронизации импортируются только письма за последние N дней. Оставьте пустым, чтобы импортировать всю папку',
+      imapUsername: 'Адрес почты / имя пользователя',
+      password: 'Пароль',
+      imapPasswordHint: 'Многие почтовые сервисы (Gmail, Outlook, Яндекс) требуют пароль приложения вместо пароля от аккаунта',
+    },
+    provider: {
+      s3: 'AWS S3 / S3-совместимое',
+      tos: 'Volcengine TOS',
+      cos: 'Tencent Cloud COS',
+    },
+    imapSecurity: {
+      tls: 'SSL/TLS',
+      starttls: 'STARTTLS',
+      none: 'Без шифрования',
     },
     comingSoon: 'Скоро',
     docHint: 'Получить учётные данные можно здесь:',
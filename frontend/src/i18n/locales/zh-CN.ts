@@ -548,6 +548,9 @@ export default {
     cancelParseConfirmBody: '确认停止解析"{title}"？已写入的分块会保留，可稍后通过"重建"重新触发；优化阶段（摘要 / 问答 / 知识图谱）的待执行任务会被立即丢弃。',
     cancelParseSubmitted: "已停止解析",
     cancelParseFailed: "停止失败，请稍后再试",
+    regenerateQuestions: "重新生成问题",
+    regenerateQuestionsSubmitted: "已提交 {count} 个分块的问题重新生成任务",
+    regenerateQuestionsFailed: "重新生成问题失败，请稍后再试",
     draft: "草稿",
     draftTip: "暂存内容，未参与检索",
     untitledDocument: "未命名文档",
@@ -1050,7 +1053,7 @@ export default {
     enableMemoryDesc: "开启后，系统将记录您的对话历史，并在后续对话中自动回忆相关内容，提供更个性化的回答。",
     autoCheckUpdate: '自动下载更新',
     autoCheckUpdateDesc: '开启后自动检查并在后台下载最新版本安装包。',
-    memoryRequiresNeo4j: "记忆功能依赖 Neo4j 图数据库，请先配置并启用 Neo4j（设置环境变量 NEO4J_ENABLE=true）后再开启此功能。",
+    memoryRequiresNeo4j: "记忆功能需要存储后端：请启用 Neo4j（设置环境变量 NEO4J_ENABLE=true），或设置 MEMORY_DRIVER=database 将记忆存储在应用数据库中，然后再开启此功能。",
     memoryHowToEnable: "查看 Neo4j 配置指南",
     vectorStoreEngine: "向量数据库引擎",
     parserEngine: "解析引擎",
@@ -2813,6 +2816,14 @@ export default {
           label: 'Azure OpenAI',
           description: 'Microsoft Azure 上的 OpenAI 服务',
         },
+        bedrock: {
+          label: 'Amazon Bedrock',
+          description: 'Amazon Bedrock 上托管的模型（Claude、Nova、Titan、Cohere）',
+        },
+        self_hosted: {
+          label: '自托管 (vLLM / llama.cpp)',
+          description: '自托管的 vLLM 或 llama.cpp 推理服务，支持多副本负载均衡与健康检查',
+        },
         aliyun: {
           label: "阿里云 DashScope",
           description: "qwen-plus, tongyi-embedding-vision-plus, qwen3-rerank, etc.",
@@ -2837,6 +2848,10 @@ export default {
           label: "Jina",
           description: "jina-clip-v1, jina-embeddings-v2-base-zh, etc.",
         },
+        cohere: {
+          label: "Cohere",
+          description: "rerank-v3.5, rerank-multilingual-v3.0, etc.",
+        },
         volcengine: {
           label: "火山引擎 Volcengine",
           description: "doubao-1-5-pro-32k-250115, doubao-embedding-vision-250615, etc.",
@@ -3772,6 +3787,12 @@ export default {
       },
       parentChildLabel: "父子分块",
       parentChildDescription: "两级分块：小的子块用于向量匹配（精准命中），大的父块返回给 LLM（更丰富上下文）。建议用于长文档（>10 页）；短 FAQ 可关闭以节省存储。",
+      tableChunkingLabel: "表格独立分块",
+      tableChunkingDescription: "将文档中的表格转换为 Markdown 并作为独立分块保存，记录列名与行范围，避免表格被切断。超长表格按行拆分。",
+      tableSummaryLabel: "生成表格摘要",
+      tableSummaryDescription: "使用知识库的摘要模型为每个表格生成简短描述，与表格一同索引，便于用自然语言提问时检索到表格。",
+      contextualRetrievalLabel: "生成分块上下文",
+      contextualRetrievalDescription: "解析时使用知识库的摘要模型为每个分块生成 1-2 句说明其在文档中位置与主题的上下文，与分块一同向量化，提升孤立片段的召回率。会增加解析耗时与模型调用量。",
       parentChunkSizeLabel: "父块大小",
       parentChunkSizeDescription: "返回给 LLM 的上下文块大小（512-8192）。默认 4096 ≈ 1000 英文 tokens，适合所有现代 LLM 上下文窗口。",
       childChunkSizeLabel: "子块大小",
@@ -3792,6 +3813,10 @@ export default {
           label: "结构感知",
           tooltip: "识别分页符、编号章节、多语言章节标记（DE/EN/ZH）、全大写标题等结构信号进行切分。适合没有 Markdown 标题的 PDF / 扫描件。"
         },
+        markdown: {
+          label: "按 Markdown 结构",
+          tooltip: "在每个标题处切分，分块记录完整标题路径（如 “指南 > 安装 > Linux”）并在向量化时作为前缀，代码块永不拆分。适合技术文档。"
+        },
         legacy: {
           label: "按长度切分",
           tooltip: "忽略结构，仅按字符数和分隔符递归切分——原始行为。当上述策略对你的内容效果不佳时使用。"
@@ -3863,6 +3888,9 @@ export default {
         description: "解析文档时调用大模型为每个分块生成相关问题，提高检索召回率。启用后会增加文档解析耗时。",
         countLabel: "生成问题数量",
         countDescription: "每个文档分块生成的问题数量（1-10）",
+        modelLabel: "问题生成模型",
+        modelDescription: "用于生成问题的对话模型，留空则使用知识库的摘要模型",
+        modelPlaceholder: "默认使用摘要模型",
       },
       multimodal: {
         label: "多模态功能",
@@ -4841,6 +4869,7 @@ export default {
     exitFullscreen: "退出全屏",
     audioLoading: "加载音频中…",
     audioNotSupported: "您的浏览器不支持音频播放",
+    playFromHere: "从此处播放",
   },
   commandPalette: {
     placeholder: "搜索知识库、文件、对话…",
@@ -5113,6 +5142,7 @@ export default {
       dataAnalysis: "数据分析",
       dataSchema: "数据结构",
       databaseQuery: "数据库查询",
+      calculator: "计算器",
     },
     summary: {
       searchKb: "检索知识库 <strong>{count}</strong> 次",
@@ -5365,6 +5395,8 @@ export default {
       thinkingDesc: "动态和反思性的问题解决思考工具",
       todoWrite: "制定计划",
       todoWriteDesc: "创建结构化的研究计划",
+      calculator: "计算器",
+      calculatorDesc: "精确计算算术表达式",
       grepChunks: "关键词搜索",
       grepChunksDesc: "快速定位包含特定关键词的文档和分块",
       knowledgeSearch: "语义搜索",
@@ -5595,6 +5627,7 @@ export default {
     connected: "已连接",
     connectionFailed: "连接失败",
     isRequired: "为必填项",
+    mustBeNumber: "必须为数字",
     credentialsLabel: "凭证",
     resourceHint: "选择要同步的内容空间/文件夹",
     untitled: "无标题",
@@ -5663,19 +5696,32 @@ export default {
     connector: {
       feishu: "飞书",
       notion: "Notion",
+      confluence: "Confluence",
       yuque: "语雀",
       rss: "RSS / Atom 订阅",
+      git: "Git 仓库",
+      bucket: "对象存储",
+      imap: "电子邮件 (IMAP)",
     },
     connectorDesc: {
       feishu: "同步飞书知识库中的文档、表格、文件",
       notion: "同步 Notion 中的页面和数据库",
+      confluence: "同步 Confluence 空间中的页面，并保留页面层级",
       yuque: "同步语雀知识库中的文档",
       rss: "同步 RSS / Atom 订阅源中的文章",
+      git: "同步 Git 仓库中的源代码与 Markdown 文档",
+      bucket: "监听 S3 / TOS / COS 存储桶前缀，自动导入新增与变更的文档并移除已删除对象",
+      imap: "定期拉取 IMAP 邮箱文件夹，将邮件连同发件人和日期转换为 Markdown 导入",
     },
     field: {
       appId: "App ID",
       appSecret: "App Secret",
       integrationToken: "Integration Token",
+      notionTokenHint: "内部集成的 Integration Token，或公共集成通过 OAuth 获得的访问令牌",
+      siteUrl: "站点地址",
+      email: "账号邮箱（可选）",
+      confluenceSiteUrlHint: "Confluence Cloud 填写 https://your-domain.atlassian.net/wiki；Data Center / Server 填写站点地址。使用 OAuth 2.0 令牌时填写 https://api.atlassian.com/ex/confluence/<cloudId>",
+      confluenceEmailHint: "Confluence Cloud 填写创建 API Token 的账号邮箱。留空时令牌以 Bearer 方式发送（Data Center 个人访问令牌或 OAuth 2.0 访问令牌）",
       apiToken: "API Token",
       baseUrl: "Base URL（可选）",
       baseUrlHint: "留空将使用语雀公有云 https://www.yuque.com；如果你使用的是语雀企业版或私有部署，请填写企业域名（例如 https://your-company.yuque.com）",
@@ -5683,6 +5729,52 @@ export default {
       feedUrlsHint: "每行一个 RSS / Atom 订阅源地址，支持同时填写多个",
       authHeaders: "自定义请求头（可选）",
       authHeadersHint: "用于访问私有订阅源，每行一个，格式为「名称: 值」，例如 Authorization: Bearer xxxx",
+      repository: "代码仓库",
+      repoUrl: "仓库地址",
+      repoUrlHint: "HTTP(S) 克隆地址，例如 https://github.com/owner/repo.git；私有仓库请在下方填写访问令牌，不要写在地址里",
+      branch: "分支（可选）",
+      branchHint: "留空将同步仓库的默认分支",
+      accessToken: "访问令牌（可选）",
+      gitTokenHint: "访问私有仓库时使用，例如 GitHub / GitLab / Gitea 的 Personal Access Token，只需仓库只读权限",
+      username: "用户名（可选）",
+      webhookSecret: "Webhook 密钥（可选）",
+      webhookSecretHint: "配置后可在代码托管平台添加 Push Webhook，推送时自动触发同步",
+      webhookUrl: "Webhook 地址",
+      webhookUrlHint: "在 GitHub / GitLab / Gitea 的仓库设置中添加此地址作为 Push 事件的 Webhook，并填写上方的 Webhook 密钥",
+      accessKeyId: "Access Key ID",
+      secretAccessKey: "Secret Access Key",
+      bucketWebhookSecretHint: "设置后可为存储桶配置事件通知，对象变更时立即触发同步",
+      bucketLocation: "存储桶",
+      provider: "服务商",
+      region: "地域",
+      regionHint: "如 cn-beijing（TOS）、ap-guangzhou（COS）、us-east-1（S3）；使用 TOS / COS 默认 Endpoint 时必填",
+      endpoint: "Endpoint（可选）",
+      endpointHint: "留空则按服务商与地域使用默认地址；MinIO 等自建 S3 兼容服务请填写完整地址",
+      bucket: "存储桶名称",
+      prefix: "前缀（可选）",
+      prefixHint: "仅同步该前缀下的对象，如 inbox/；留空同步整个存储桶",
+      forcePathStyle: "使用路径风格访问（endpoint/bucket）",
+      bucketWebhookUrlHint: "将此地址配置为存储桶事件通知的接收地址（或由云函数转发），请求头携带 Authorization: Bearer <Webhook 密钥>",
+      imapServer: "邮件服务器",
+      imapHost: "IMAP 服务器",
+      security: "连接加密",
+      port: "端口（可选）",
+      portHint: "留空时 SSL/TLS 使用 993，STARTTLS / 不加密使用 143",
+      sinceDays: "首次同步范围（天，可选）",
+      sinceDaysHint: "首次同步只导入最近 N 天收到的邮件，留空则导入整个文件夹",
+      imapUsername: "邮箱地址 / 用户名",
+      password: "密码",
+      imapPasswordHint: "Gmail、QQ 邮箱、163、Outlook 等通常需要使用应用专用密码或授权码，而不是登录密码",
+    },
+    provider: {
+      s3: "AWS S3 / S3 兼容",
+      tos: "火山引擎 TOS",
+      cos: "腾讯云 COS",
+    },
+    imapSecurity: {
+      tls: "SSL/TLS",
+      starttls: "STARTTLS",
+      none: "不加密",
     },
     comingSoon: "即将支持",
     docHint: "在以下地址获取凭证：",
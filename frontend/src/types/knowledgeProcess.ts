@@ -16,6 +16,9 @@ export interface ChunkingConfigOverride {
   strategy?: string
   token_limit?: number
   languages?: string[]
+  table_chunking?: boolean
+  table_summary?: boolean
+  contextual_retrieval?: boolean
 }
 
 export interface VLMConfigOverride {
@@ -32,6 +35,7 @@ export interface ASRConfigOverride {
 export interface QuestionGenerationConfigOverride {
   enabled?: boolean
   question_count?: number
+  model_id?: string
 }
 
 export interface GraphNodeOverride {
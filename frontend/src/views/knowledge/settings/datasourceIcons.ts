@@ -1,13 +1,21 @@
 import feishuIcon from '@/assets/img/datasource-feishu.ico'
 import notionIcon from '@/assets/img/datasource-notion.ico'
 import yuqueIcon from '@/assets/img/datasource-yuque.ico'
+import confluenceIcon from '@/assets/img/datasource-confluence.svg'
 import rssIcon from '@/assets/img/datasource-rss.svg'
+import gitIcon from '@/assets/img/datasource-git.svg'
+import bucketIcon from '@/assets/img/datasource-bucket.svg'
+import imapIcon from '@/assets/img/datasource-imap.svg'
 
 export const datasourceIconMap: Record<string, string> = {
   feishu: feishuIcon,
   notion: notionIcon,
+  confluence: confluenceIcon,
   yuque: yuqueIcon,
   rss: rssIcon,
+  git: gitIcon,
+  bucket: bucketIcon,
+  imap: imapIcon,
 }
 
 export function getDatasourceIconUrl(type: string): string | undefined {
@@ -127,6 +127,7 @@ var toolDisplayNames = map[string]string{
 	agenttools.ToolDataSchema:          "查看数据结构",
 	agenttools.ToolWebSearch:           "搜索网页",
 	agenttools.ToolWebFetch:            "获取网页",
+	agenttools.ToolCalculator:          "计算器",
 	agenttools.ToolExecuteSkillScript:  "执行技能脚本",
 	agenttools.ToolReadSkill:           "读取技能",
 }
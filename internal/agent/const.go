@@ -72,6 +72,15 @@ func (e *AgentEngine) getLLMCallTimeout() time.Duration {
 	return defaultLLMCallTimeout
 }
 
+// getRunTimeout returns the configured limit on the whole ReAct loop, or 0
+// when the run is only bounded by MaxIterations.
+func (e *AgentEngine) getRunTimeout() time.Duration {
+	if e.config.RunTimeout > 0 {
+		return time.Duration(e.config.RunTimeout) * time.Second
+	}
+	return 0
+}
+
 // generateEventID generates a unique event ID with type suffix for better traceability
 func generateEventID(suffix string) string {
 	return fmt.Sprintf("%s-%s", uuid.New().String()[:8], suffix)
@@ -351,8 +351,10 @@ func (e *AgentEngine) executeLoop(
 	messageID string,
 ) (*types.AgentState, error) {
 	startTime := time.Now()
+	runTimeout := e.getRunTimeout()
 	common.PipelineInfo(ctx, "Agent", "loop_start", map[string]interface{}{
 		"max_iterations": e.config.MaxIterations,
+		"run_timeout_s":  int(runTimeout.Seconds()),
 	})
 
 	// Guarantee exactly-one EventAgentComplete emission on every exit path
@@ -393,6 +395,18 @@ loop:
 		default:
 		}
 
+		// The run timeout is checked between rounds so a round's tool calls
+		// and messages are never cut in half; the answer is then synthesized
+		// from the results gathered so far, as on reaching MaxIterations.
+		if runTimeout > 0 && time.Since(startTime) >= runTimeout {
+			logger.Warnf(ctx, "[Agent] Run timeout %s reached at round %d", runTimeout, state.CurrentRound+1)
+			common.PipelineWarn(ctx, "Agent", "run_timeout_reached", map[string]interface{}{
+				"iterations":    state.CurrentRound,
+				"run_timeout_s": int(runTimeout.Seconds()),
+			})
+			break loop
+		}
+
 		// Each iteration runs inside an "agent.round.<N>" Langfuse span.
 		// We execute the body in a closure so `defer span.Finish()` fires at
 		// every exit path (break/continue/next) without having to sprinkle
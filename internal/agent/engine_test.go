@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/models/chat"
@@ -63,6 +64,12 @@ func withMaxIterations(n int) testEngineOption {
 	}
 }
 
+func withRunTimeout(seconds int) testEngineOption {
+	return func(cfg *types.AgentConfig) {
+		cfg.RunTimeout = seconds
+	}
+}
+
 func newTestEngine(t *testing.T, chatModel chat.Chat, opts ...testEngineOption) *AgentEngine {
 	t.Helper()
 	cfg := &types.AgentConfig{
@@ -180,6 +187,44 @@ func TestExecuteLoop_EmptyThenNonEmpty_ShouldRetryAndComplete(t *testing.T) {
 	assert.Equal(t, "Here is the answer.", state.FinalAnswer)
 }
 
+// ---------------------------------------------------------------------------
+// TC4b: Run timeout reached between rounds → final answer is synthesized
+// ---------------------------------------------------------------------------
+
+// slowChat delays each stream so a round outlasts the run timeout.
+type slowChat struct {
+	*mockChat
+	delay time.Duration
+}
+
+func (s *slowChat) ChatStream(ctx context.Context, msgs []chat.Message, opts *chat.ChatOptions) (<-chan types.StreamResponse, error) {
+	time.Sleep(s.delay)
+	return s.mockChat.ChatStream(ctx, msgs, opts)
+}
+
+func TestExecuteLoop_RunTimeout_SynthesizesFinalAnswer(t *testing.T) {
+	mock := &mockChat{
+		responses: []mockResponse{
+			// Round 1: empty content would normally be retried.
+			{chunks: []types.StreamResponse{{Done: true}}},
+			// Final answer synthesis after the timeout.
+			{chunks: []types.StreamResponse{
+				{Content: "Answer from gathered results", Done: true},
+			}},
+		},
+	}
+
+	engine := newTestEngine(t, &slowChat{mockChat: mock, delay: 1100 * time.Millisecond}, withRunTimeout(1))
+	state := &types.AgentState{}
+
+	_, err := engine.executeLoop(context.Background(), state, "test query", emptyMessages(), emptyTools(), "sess-1", "msg-1")
+
+	assert.NoError(t, err)
+	assert.True(t, state.IsComplete)
+	assert.Equal(t, "Answer from gathered results", state.FinalAnswer)
+	assert.Equal(t, 2, mock.callCount, "no further round may start once the run timeout is reached")
+}
+
 // ---------------------------------------------------------------------------
 // TC5: FinishReason propagation through streamThinkingToEventBus
 // ---------------------------------------------------------------------------
@@ -21,6 +21,7 @@ package token
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/tiktoken-go/tokenizer"
@@ -47,6 +48,35 @@ func NewEstimator() (*Estimator, error) {
 	return &Estimator{codec: codec}, nil
 }
 
+// NewEstimatorForModel creates a token estimator using the encoding of the
+// named model: o200k_base for the GPT-4o, GPT-4.1, GPT-5 and o-series
+// families, cl100k_base for every other model, including non-OpenAI ones.
+func NewEstimatorForModel(modelName string) (*Estimator, error) {
+	codec, err := tokenizer.Get(encodingForModel(modelName))
+	if err != nil {
+		return nil, fmt.Errorf("token: failed to initialize tokenizer: %w", err)
+	}
+	return &Estimator{codec: codec}, nil
+}
+
+// o200kModelPrefixes are the model name prefixes that use o200k_base.
+var o200kModelPrefixes = []string{"gpt-4o", "chatgpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "gpt-oss", "o1", "o3", "o4"}
+
+// encodingForModel picks the encoding of a model name, ignoring any
+// "vendor/" prefix.
+func encodingForModel(modelName string) tokenizer.Encoding {
+	name := strings.ToLower(strings.TrimSpace(modelName))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, prefix := range o200kModelPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return tokenizer.O200kBase
+		}
+	}
+	return tokenizer.Cl100kBase
+}
+
 // EstimateMessages returns the estimated token count for a slice of messages.
 // Prefer using API Usage for the full context and this method only for deltas.
 func (e *Estimator) EstimateMessages(messages []chat.Message) int {
@@ -85,3 +115,26 @@ func (e *Estimator) EstimateMessage(msg *chat.Message) int {
 
 	return tokens
 }
+
+// Truncate returns the longest prefix of s that fits in maxTokens tokens.
+func (e *Estimator) Truncate(s string, maxTokens int) string {
+	if maxTokens <= 0 {
+		return ""
+	}
+	ids, _, err := e.codec.Encode(s)
+	if err != nil {
+		if runes := []rune(s); len(runes) > maxTokens*4 {
+			return string(runes[:maxTokens*4])
+		}
+		return s
+	}
+	if len(ids) <= maxTokens {
+		return s
+	}
+	prefix, err := e.codec.Decode(ids[:maxTokens])
+	if err != nil {
+		return ""
+	}
+	// A multi-byte character may be split at the cut.
+	return strings.ToValidUTF8(prefix, "")
+}
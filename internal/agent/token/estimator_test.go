@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tiktoken-go/tokenizer"
 )
 
 func TestEstimator(t *testing.T) {
@@ -331,3 +333,28 @@ func TestGroupToolMessages(t *testing.T) {
 		fmt.Println(string(b))
 	})
 }
+
+func TestEncodingForModel(t *testing.T) {
+	assert.Equal(t, tokenizer.O200kBase, encodingForModel("gpt-4o-mini"))
+	assert.Equal(t, tokenizer.O200kBase, encodingForModel("openai/GPT-4.1"))
+	assert.Equal(t, tokenizer.O200kBase, encodingForModel("o3-mini"))
+	assert.Equal(t, tokenizer.Cl100kBase, encodingForModel("gpt-4-turbo"))
+	assert.Equal(t, tokenizer.Cl100kBase, encodingForModel("qwen-plus"))
+	assert.Equal(t, tokenizer.Cl100kBase, encodingForModel(""))
+}
+
+func TestEstimatorTruncate(t *testing.T) {
+	e, err := NewEstimatorForModel("gpt-4o")
+	require.NoError(t, err)
+
+	text := strings.Repeat("hello world ", 50)
+	assert.Equal(t, text, e.Truncate(text, 1000))
+	assert.Equal(t, "", e.Truncate(text, 0))
+
+	cut := e.Truncate(text, 10)
+	assert.True(t, strings.HasPrefix(text, cut))
+	assert.LessOrEqual(t, e.EstimateString(cut), 10)
+
+	zh := e.Truncate(strings.Repeat("你好世界", 20), 5)
+	assert.True(t, utf8.ValidString(zh))
+}
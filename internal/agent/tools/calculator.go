@@ -0,0 +1,382 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// maxExpressionLength caps the calculator input so a runaway expression
+// cannot tie up the agent.
+const maxExpressionLength = 1000
+
+var calculatorTool = BaseTool{
+	name: ToolCalculator,
+	description: `Evaluate an arithmetic expression exactly instead of computing it in your head.
+
+## When to Use
+- Sums, differences, ratios, percentages and growth rates of figures found in documents
+- Unit conversions and any multi-step arithmetic
+- Whenever a number in the answer is derived rather than quoted
+
+## Syntax
+- Operators: + - * / % and ^ (power, also **), with parentheses
+- Constants: pi, e
+- Functions: abs, sqrt, pow(x, y), exp, ln, log (natural), log10, log2, sin, cos, tan, asin, acos, atan, floor, ceil, round, min(a, b, ...), max(a, b, ...)
+- Numbers may use scientific notation (1.5e6); do not use thousands separators or units
+
+## Output
+Returns the value of the expression, or an error describing what could not be evaluated.`,
+	schema: json.RawMessage(`{
+  "type": "object",
+  "properties": {
+    "expression": {
+      "type": "string",
+      "description": "The arithmetic expression to evaluate, e.g. '(1250 - 980) / 980 * 100'"
+    }
+  },
+  "required": ["expression"]
+}`),
+}
+
+// CalculatorInput defines the input parameters for the calculator tool
+type CalculatorInput struct {
+	Expression string `json:"expression"`
+}
+
+// CalculatorTool evaluates arithmetic expressions for the agent
+type CalculatorTool struct {
+	BaseTool
+}
+
+// NewCalculatorTool creates a new calculator tool instance
+func NewCalculatorTool() *CalculatorTool {
+	return &CalculatorTool{BaseTool: calculatorTool}
+}
+
+// Execute evaluates the expression in args
+func (t *CalculatorTool) Execute(ctx context.Context, args json.RawMessage) (*types.ToolResult, error) {
+	var input CalculatorInput
+	if err := json.Unmarshal(args, &input); err != nil {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to parse args: %v", err),
+		}, err
+	}
+
+	value, err := EvaluateExpression(input.Expression)
+	if err != nil {
+		logger.Infof(ctx, "[Tool][Calculator] Failed to evaluate %q: %v", input.Expression, err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to evaluate expression: %v", err),
+		}, err
+	}
+
+	result := strconv.FormatFloat(value, 'g', 15, 64)
+	return &types.ToolResult{
+		Success: true,
+		Output:  fmt.Sprintf("%s = %s", strings.TrimSpace(input.Expression), result),
+		Data: map[string]interface{}{
+			"expression": input.Expression,
+			"result":     value,
+		},
+	}, nil
+}
+
+// EvaluateExpression evaluates an arithmetic expression. It supports
+// + - * / %, ^ or ** for power, parentheses, the constants pi and e and a
+// fixed set of math functions. Results that are not finite are errors.
+func EvaluateExpression(expr string) (float64, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return 0, fmt.Errorf("expression is empty")
+	}
+	if len(expr) > maxExpressionLength {
+		return 0, fmt.Errorf("expression is longer than %d characters", maxExpressionLength)
+	}
+	p := &exprParser{src: expr}
+	value, err := p.parseSum()
+	if err != nil {
+		return 0, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return 0, fmt.Errorf("unexpected %q at position %d", p.src[p.pos:], p.pos+1)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, fmt.Errorf("result is not a finite number")
+	}
+	return value, nil
+}
+
+// exprParser is a recursive descent parser over
+//
+//	sum     = product { ("+" | "-") product }
+//	product = unary { ("*" | "/" | "%") unary }
+//	unary   = ("+" | "-") unary | power
+//	power   = primary [ ("^" | "**") unary ]
+//	primary = number | name | name "(" sum { "," sum } ")" | "(" sum ")"
+//
+// so power binds tighter than unary minus (-2^2 = -4) and is right
+// associative.
+type exprParser struct {
+	src string
+	pos int
+}
+
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+// peek skips spaces and reports whether the input continues with tok.
+func (p *exprParser) peek(tok string) bool {
+	p.skipSpace()
+	return strings.HasPrefix(p.src[p.pos:], tok)
+}
+
+func (p *exprParser) parseSum() (float64, error) {
+	left, err := p.parseProduct()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		switch {
+		case p.peek("+"):
+			p.pos++
+			right, err := p.parseProduct()
+			if err != nil {
+				return 0, err
+			}
+			left += right
+		case p.peek("-"):
+			p.pos++
+			right, err := p.parseProduct()
+			if err != nil {
+				return 0, err
+			}
+			left -= right
+		default:
+			return left, nil
+		}
+	}
+}
+
+func (p *exprParser) parseProduct() (float64, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		var op byte
+		switch {
+		case p.peek("**"):
+			return left, nil
+		case p.peek("*"), p.peek("/"), p.peek("%"):
+			op = p.src[p.pos]
+		default:
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case '*':
+			left *= right
+		case '/':
+			if right == 0 {
+				return 0, fmt.Errorf("division by zero")
+			}
+			left /= right
+		case '%':
+			if right == 0 {
+				return 0, fmt.Errorf("modulo by zero")
+			}
+			left = math.Mod(left, right)
+		}
+	}
+}
+
+func (p *exprParser) parseUnary() (float64, error) {
+	switch {
+	case p.peek("-"):
+		p.pos++
+		v, err := p.parseUnary()
+		return -v, err
+	case p.peek("+"):
+		p.pos++
+		return p.parseUnary()
+	}
+	return p.parsePower()
+}
+
+func (p *exprParser) parsePower() (float64, error) {
+	base, err := p.parsePrimary()
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case p.peek("**"):
+		p.pos += 2
+	case p.peek("^"):
+		p.pos++
+	default:
+		return base, nil
+	}
+	exp, err := p.parseUnary()
+	if err != nil {
+		return 0, err
+	}
+	return math.Pow(base, exp), nil
+}
+
+func (p *exprParser) parsePrimary() (float64, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return 0, fmt.Errorf("unexpected end of expression")
+	}
+	c := p.src[p.pos]
+	switch {
+	case c == '(':
+		p.pos++
+		v, err := p.parseSum()
+		if err != nil {
+			return 0, err
+		}
+		if !p.peek(")") {
+			return 0, fmt.Errorf("missing closing parenthesis")
+		}
+		p.pos++
+		return v, nil
+	case c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case unicode.IsLetter(rune(c)):
+		return p.parseName()
+	}
+	return 0, fmt.Errorf("unexpected %q at position %d", string(c), p.pos+1)
+}
+
+func (p *exprParser) parseNumber() (float64, error) {
+	start := p.pos
+	for p.pos < len(p.src) && (p.src[p.pos] == '.' || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+		p.pos++
+	}
+	// Exponent part, only when followed by digits so "2e" stays an error.
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		i := p.pos + 1
+		if i < len(p.src) && (p.src[i] == '+' || p.src[i] == '-') {
+			i++
+		}
+		if i < len(p.src) && p.src[i] >= '0' && p.src[i] <= '9' {
+			for i < len(p.src) && p.src[i] >= '0' && p.src[i] <= '9' {
+				i++
+			}
+			p.pos = i
+		}
+	}
+	v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", p.src[start:p.pos])
+	}
+	return v, nil
+}
+
+func (p *exprParser) parseName() (float64, error) {
+	start := p.pos
+	for p.pos < len(p.src) && (unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+		p.pos++
+	}
+	name := strings.ToLower(p.src[start:p.pos])
+
+	if !p.peek("(") {
+		switch name {
+		case "pi":
+			return math.Pi, nil
+		case "e":
+			return math.E, nil
+		}
+		return 0, fmt.Errorf("unknown name %q", name)
+	}
+	p.pos++
+
+	var args []float64
+	if !p.peek(")") {
+		for {
+			v, err := p.parseSum()
+			if err != nil {
+				return 0, err
+			}
+			args = append(args, v)
+			if !p.peek(",") {
+				break
+			}
+			p.pos++
+		}
+	}
+	if !p.peek(")") {
+		return 0, fmt.Errorf("missing closing parenthesis after arguments of %s", name)
+	}
+	p.pos++
+	return callMathFunction(name, args)
+}
+
+// unaryMathFunctions are the one-argument functions of the calculator.
+var unaryMathFunctions = map[string]func(float64) float64{
+	"abs":   math.Abs,
+	"sqrt":  math.Sqrt,
+	"exp":   math.Exp,
+	"ln":    math.Log,
+	"log":   math.Log,
+	"log10": math.Log10,
+	"log2":  math.Log2,
+	"sin":   math.Sin,
+	"cos":   math.Cos,
+	"tan":   math.Tan,
+	"asin":  math.Asin,
+	"acos":  math.Acos,
+	"atan":  math.Atan,
+	"floor": math.Floor,
+	"ceil":  math.Ceil,
+	"round": math.Round,
+}
+
+func callMathFunction(name string, args []float64) (float64, error) {
+	if fn, ok := unaryMathFunctions[name]; ok {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("%s takes 1 argument, got %d", name, len(args))
+		}
+		return fn(args[0]), nil
+	}
+	switch name {
+	case "pow":
+		if len(args) != 2 {
+			return 0, fmt.Errorf("pow takes 2 arguments, got %d", len(args))
+		}
+		return math.Pow(args[0], args[1]), nil
+	case "min", "max":
+		if len(args) == 0 {
+			return 0, fmt.Errorf("%s needs at least 1 argument", name)
+		}
+		v := args[0]
+		for _, a := range args[1:] {
+			if name == "min" {
+				v = math.Min(v, a)
+			} else {
+				v = math.Max(v, a)
+			}
+		}
+		return v, nil
+	}
+	return 0, fmt.Errorf("unknown function %q", name)
+}
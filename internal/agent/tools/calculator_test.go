@@ -0,0 +1,79 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	cases := []struct {
+		expr string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"10 / 4", 2.5},
+		{"10 % 4", 2},
+		{"2 ^ 3 ^ 2", 512},
+		{"2 ** 10", 1024},
+		{"2 * 3 ^ 2", 18},
+		{"-2 ^ 2", -4},
+		{"2 ^ -1", 0.5},
+		{"--3", 3},
+		{"1.5e3 + .5", 1500.5},
+		{"(1250 - 980) / 980 * 100", (1250.0 - 980.0) / 980.0 * 100},
+		{"sqrt(16) + abs(-2)", 6},
+		{"pow(2, 0.5)", math.Sqrt2},
+		{"max(1, 7, 3) - min(4, 2)", 5},
+		{"round(2.5) + floor(1.9) + ceil(1.1)", 6},
+		{"log10(1000) + ln(e)", 4},
+		{"2 * PI", 2 * math.Pi},
+	}
+	for _, c := range cases {
+		got, err := EvaluateExpression(c.expr)
+		require.NoError(t, err, c.expr)
+		assert.InDelta(t, c.want, got, 1e-9, c.expr)
+	}
+}
+
+func TestEvaluateExpressionErrors(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"1 +",
+		"(1 + 2",
+		"1 / 0",
+		"5 % 0",
+		"sqrt(-1)",
+		"foo(1)",
+		"x + 1",
+		"sqrt(1, 2)",
+		"1,000",
+		"2 3",
+		"2e",
+	} {
+		_, err := EvaluateExpression(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestCalculatorToolExecute(t *testing.T) {
+	tool := NewCalculatorTool()
+	assert.Equal(t, ToolCalculator, tool.Name())
+
+	args, _ := json.Marshal(CalculatorInput{Expression: "12 * 12"})
+	res, err := tool.Execute(context.Background(), args)
+	require.NoError(t, err)
+	assert.True(t, res.Success)
+	assert.Equal(t, "12 * 12 = 144", res.Output)
+	assert.Equal(t, 144.0, res.Data["result"])
+
+	args, _ = json.Marshal(CalculatorInput{Expression: "1 / 0"})
+	res, err = tool.Execute(context.Background(), args)
+	assert.Error(t, err)
+	assert.False(t, res.Success)
+}
@@ -35,6 +35,10 @@ func (f *fakeFileService) GetFile(ctx context.Context, filePath string) (io.Read
 	}
 	return fn()
 }
+
+func (f *fakeFileService) GetFileRange(ctx context.Context, filePath string, offset, length int64) (io.ReadCloser, int64, error) {
+	return nil, 0, errors.New("not implemented")
+}
 func (f *fakeFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
 	// Return a URL that DuckDB would NOT be able to open on its own; the
 	// production code must *not* pass this through to DuckDB.
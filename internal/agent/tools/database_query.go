@@ -8,6 +8,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/Tencent/WeKnora/internal/utils"
 	"gorm.io/gorm"
 )
@@ -19,6 +20,7 @@ var databaseQueryTool = BaseTool{
 ## Security Features
 - Automatic tenant_id injection: All queries are automatically filtered by the logged-in user's tenant_id
 - Automatic soft-delete filtering: All queries are automatically filtered to include only records with deleted_at IS NULL
+- Document access control: Documents the caller may not read are automatically filtered out, along with their chunks
 - Read-only queries: Only SELECT statements are allowed
 - Safe tables: Only allow queries on authorized tables (knowledge_bases, knowledges, chunks)
 
@@ -104,16 +106,22 @@ type DatabaseQueryInput struct {
 // DatabaseQueryTool allows AI to query the database with auto-injected tenant_id for security
 type DatabaseQueryTool struct {
 	BaseTool
-	db            *gorm.DB
-	searchTargets types.SearchTargets
+	db               *gorm.DB
+	knowledgeService interfaces.KnowledgeService
+	searchTargets    types.SearchTargets
 }
 
 // NewDatabaseQueryTool creates a new database query tool
-func NewDatabaseQueryTool(db *gorm.DB, searchTargets types.SearchTargets) *DatabaseQueryTool {
+func NewDatabaseQueryTool(
+	db *gorm.DB,
+	knowledgeService interfaces.KnowledgeService,
+	searchTargets types.SearchTargets,
+) *DatabaseQueryTool {
 	return &DatabaseQueryTool{
-		BaseTool:      databaseQueryTool,
-		db:            db,
-		searchTargets: searchTargets,
+		BaseTool:         databaseQueryTool,
+		db:               db,
+		knowledgeService: knowledgeService,
+		searchTargets:    searchTargets,
 	}
 }
 
@@ -150,7 +158,16 @@ func (t *DatabaseQueryTool) Execute(ctx context.Context, args json.RawMessage) (
 
 	// Validate and secure the SQL query
 	logger.Debugf(ctx, "[Tool][DatabaseQuery] Validating and securing SQL...")
-	securedSQL, err := t.validateAndSecureSQL(input.SQL, tenantID)
+	deniedKnowledgeIDs, err := aclDeniedKnowledgeIDs(ctx, t.searchTargets.GetAllKnowledgeBaseIDs(),
+		t.knowledgeService.GetRepository().ListACLKnowledge)
+	if err != nil {
+		logger.Errorf(ctx, "[Tool][DatabaseQuery] Failed to load document ACLs: %v", err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to load document ACLs: %v", err),
+		}, err
+	}
+	securedSQL, err := t.validateAndSecureSQL(input.SQL, tenantID, deniedKnowledgeIDs)
 	if err != nil {
 		logger.Errorf(ctx, "[Tool][DatabaseQuery] SQL validation failed: %v", err)
 		return &types.ToolResult{
@@ -254,7 +271,11 @@ func (t *DatabaseQueryTool) Execute(ctx context.Context, args json.RawMessage) (
 }
 
 // validateAndSecureSQL validates the SQL query and injects tenant_id conditions
-func (t *DatabaseQueryTool) validateAndSecureSQL(sqlQuery string, tenantID uint64) (string, error) {
+func (t *DatabaseQueryTool) validateAndSecureSQL(
+	sqlQuery string,
+	tenantID uint64,
+	deniedKnowledgeIDs []string,
+) (string, error) {
 	securedSQL, validationResult, err := utils.ValidateAndSecureSQL(
 		sqlQuery,
 		utils.WithSecurityDefaults(tenantID),
@@ -262,6 +283,7 @@ func (t *DatabaseQueryTool) validateAndSecureSQL(sqlQuery string, tenantID uint6
 		utils.WithHiddenKBFilter(),
 		utils.WithInjectionRiskCheck(),
 		utils.WithSearchScopes(searchScopesFromTargets(t.searchTargets)),
+		utils.WithExcludedKnowledgeFilter(deniedKnowledgeIDs),
 	)
 	if err != nil {
 		return "", err
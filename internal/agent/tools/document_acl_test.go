@@ -0,0 +1,222 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type aclKnowledgeRepo struct {
+	interfaces.KnowledgeRepository
+	restricted []*types.Knowledge
+}
+
+func (r *aclKnowledgeRepo) ListACLKnowledge(context.Context, []string) ([]*types.Knowledge, error) {
+	return r.restricted, nil
+}
+
+type aclKnowledgeService struct {
+	interfaces.KnowledgeService
+	repo *aclKnowledgeRepo
+}
+
+func (s *aclKnowledgeService) GetKnowledgeByIDOnly(_ context.Context, id string) (*types.Knowledge, error) {
+	for _, k := range s.repo.restricted {
+		if k.ID == id {
+			return k, nil
+		}
+	}
+	return &types.Knowledge{ID: id, TenantID: 7, KnowledgeBaseID: "kb-1"}, nil
+}
+
+func (s *aclKnowledgeService) GetRepository() interfaces.KnowledgeRepository {
+	return s.repo
+}
+
+type aclChunkRepo struct {
+	interfaces.ChunkRepository
+	chunks []*types.Chunk
+}
+
+func (r *aclChunkRepo) ListPagedChunksByKnowledgeID(
+	_ context.Context, _ uint64, knowledgeID string, _ *types.Pagination,
+	_ []types.ChunkType, _, _, _, _, _ string,
+) ([]*types.Chunk, int64, error) {
+	var chunks []*types.Chunk
+	for _, c := range r.chunks {
+		if c.KnowledgeID == knowledgeID {
+			chunks = append(chunks, c)
+		}
+	}
+	return chunks, int64(len(chunks)), nil
+}
+
+func (r *aclChunkRepo) ListChunksByParentIDs(context.Context, uint64, []string) ([]*types.Chunk, error) {
+	return nil, nil
+}
+
+type aclChunkService struct {
+	interfaces.ChunkService
+	repo *aclChunkRepo
+}
+
+func (s *aclChunkService) GetChunkByIDOnly(_ context.Context, id string) (*types.Chunk, error) {
+	for _, c := range s.repo.chunks {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *aclChunkService) GetRepository() interfaces.ChunkRepository {
+	return s.repo
+}
+
+// newACLFixture returns services holding doc-secret, readable by u1 only, and
+// doc-open without an ACL, each with one chunk.
+func newACLFixture(t *testing.T) (*aclKnowledgeService, *aclChunkService, types.SearchTargets) {
+	t.Helper()
+	secret := &types.Knowledge{ID: "doc-secret", TenantID: 7, KnowledgeBaseID: "kb-1", Title: "secret"}
+	if err := secret.SetACL(&types.KnowledgeACL{Users: []string{"u1"}}); err != nil {
+		t.Fatalf("SetACL() error = %v", err)
+	}
+	knowledgeService := &aclKnowledgeService{repo: &aclKnowledgeRepo{restricted: []*types.Knowledge{secret}}}
+	chunkService := &aclChunkService{repo: &aclChunkRepo{chunks: []*types.Chunk{
+		{ID: "chunk-secret", KnowledgeID: "doc-secret", KnowledgeBaseID: "kb-1", Content: "payroll", ChunkType: types.ChunkTypeText},
+		{ID: "chunk-open", KnowledgeID: "doc-open", KnowledgeBaseID: "kb-1", Content: "handbook", ChunkType: types.ChunkTypeText},
+	}}}
+	targets := types.SearchTargets{{Type: types.SearchTargetTypeKnowledgeBase, KnowledgeBaseID: "kb-1", TenantID: 7}}
+	return knowledgeService, chunkService, targets
+}
+
+func aclUserContext(userID string) context.Context {
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(7))
+	ctx = context.WithValue(ctx, types.UserIDContextKey, userID)
+	return context.WithValue(ctx, types.TenantRoleContextKey, types.TenantRoleViewer)
+}
+
+func TestACLDeniedKnowledgeIDs(t *testing.T) {
+	knowledgeService, _, _ := newACLFixture(t)
+	list := knowledgeService.repo.ListACLKnowledge
+
+	denied, err := aclDeniedKnowledgeIDs(aclUserContext("u2"), []string{"kb-1"}, list)
+	if err != nil {
+		t.Fatalf("aclDeniedKnowledgeIDs() error = %v", err)
+	}
+	if len(denied) != 1 || denied[0] != "doc-secret" {
+		t.Fatalf("expected doc-secret denied for u2, got %v", denied)
+	}
+
+	denied, err = aclDeniedKnowledgeIDs(aclUserContext("u1"), []string{"kb-1"}, list)
+	if err != nil {
+		t.Fatalf("aclDeniedKnowledgeIDs() error = %v", err)
+	}
+	if len(denied) != 0 {
+		t.Fatalf("u1 is listed in the ACL, got %v", denied)
+	}
+}
+
+func TestListKnowledgeChunksTool_HonorsDocumentACL(t *testing.T) {
+	knowledgeService, chunkService, targets := newACLFixture(t)
+	tool := NewListKnowledgeChunksTool(knowledgeService, chunkService, targets)
+
+	for _, args := range []string{`{"knowledge_id":"doc-secret"}`, `{"chunk_id":"chunk-secret"}`} {
+		result, _ := tool.Execute(aclUserContext("u2"), json.RawMessage(args))
+		if result.Success || strings.Contains(result.Output, "payroll") {
+			t.Fatalf("%s: u2 must not read doc-secret, got %+v", args, result)
+		}
+
+		result, err := tool.Execute(aclUserContext("u1"), json.RawMessage(args))
+		if err != nil || !result.Success || !strings.Contains(result.Output, "payroll") {
+			t.Fatalf("%s: u1 must read doc-secret, got %+v (err %v)", args, result, err)
+		}
+	}
+}
+
+func TestGetDocumentInfoTool_HonorsDocumentACL(t *testing.T) {
+	knowledgeService, chunkService, targets := newACLFixture(t)
+	tool := NewGetDocumentInfoTool(knowledgeService, chunkService, targets)
+
+	result, err := tool.Execute(aclUserContext("u2"),
+		json.RawMessage(`{"knowledge_ids":["doc-secret","doc-open"],"faq_ids":["chunk-secret"]}`))
+	if err != nil || !result.Success {
+		t.Fatalf("doc-open must still be returned, got %+v (err %v)", result, err)
+	}
+	if !strings.Contains(result.Output, "Successfully retrieved 1 / 3 entries") {
+		t.Fatalf("doc-secret and its chunk must be hidden from u2:\n%s", result.Output)
+	}
+
+	result, err = tool.Execute(aclUserContext("u1"), json.RawMessage(`{"knowledge_ids":["doc-secret"]}`))
+	if err != nil || !result.Success || !strings.Contains(result.Output, "secret") {
+		t.Fatalf("u1 must read doc-secret, got %+v (err %v)", result, err)
+	}
+}
+
+func TestWikiReadSourceDocTool_HonorsDocumentACL(t *testing.T) {
+	knowledgeService, chunkService, _ := newACLFixture(t)
+	tool := NewWikiReadSourceDocTool(knowledgeService, chunkService)
+
+	result, _ := tool.Execute(aclUserContext("u2"), json.RawMessage(`{"knowledge_id":"doc-secret"}`))
+	if result.Success || strings.Contains(result.Output, "payroll") {
+		t.Fatalf("u2 must not read doc-secret, got %+v", result)
+	}
+
+	result, _ = tool.Execute(aclUserContext("u1"), json.RawMessage(`{"knowledge_id":"doc-secret"}`))
+	if !result.Success || !strings.Contains(result.Output, "payroll") {
+		t.Fatalf("u1 must read doc-secret, got %+v", result)
+	}
+}
+
+func TestGrepChunksTool_ExcludesACLDeniedDocumentsInSQL(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{DryRun: true})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	var statement *gorm.Statement
+	if err := db.Callback().Query().After("gorm:query").Register("test:capture", func(tx *gorm.DB) {
+		statement = tx.Statement
+	}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	knowledgeService, _, targets := newACLFixture(t)
+	tool := NewGrepChunksTool(db, knowledgeService, targets)
+	result, err := tool.Execute(aclUserContext("u2"), json.RawMessage(`{"query":"payroll"}`))
+	if err != nil || !result.Success {
+		t.Fatalf("Execute() = %+v, %v", result, err)
+	}
+	if statement == nil || !strings.Contains(statement.SQL.String(), "chunks.knowledge_id NOT IN") {
+		t.Fatalf("expected the denied documents to be excluded in SQL, got %v", statement)
+	}
+	if !strings.Contains(db.Dialector.Explain(statement.SQL.String(), statement.Vars...), `"doc-secret"`) {
+		t.Fatalf("expected doc-secret among the excluded documents: %v", statement.Vars)
+	}
+
+	statement = nil
+	if _, err := tool.Execute(aclUserContext("u1"), json.RawMessage(`{"query":"payroll"}`)); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if statement == nil || strings.Contains(statement.SQL.String(), "NOT IN") {
+		t.Fatalf("u1 reads every document, nothing must be excluded: %v", statement)
+	}
+}
+
+func TestDatabaseQueryTool_ExcludesACLDeniedDocuments(t *testing.T) {
+	knowledgeService, _, targets := newACLFixture(t)
+	tool := NewDatabaseQueryTool(nil, knowledgeService, targets)
+
+	securedSQL, err := tool.validateAndSecureSQL("SELECT content FROM chunks", 7, []string{"doc-secret"})
+	if err != nil {
+		t.Fatalf("validateAndSecureSQL() error = %v", err)
+	}
+	if !strings.Contains(securedSQL, "chunks.knowledge_id NOT IN ('doc-secret')") {
+		t.Fatalf("expected doc-secret to be excluded:\n%s", securedSQL)
+	}
+}
@@ -155,6 +155,17 @@ func (t *GetDocumentInfoTool) Execute(ctx context.Context, args json.RawMessage)
 				mu.Unlock()
 				return
 			}
+			readable, aclErr := chunkReadable(ctx, chunk, t.knowledgeService)
+			if aclErr != nil || !readable {
+				mu.Lock()
+				if aclErr != nil {
+					results["faq:"+id] = &docInfo{err: fmt.Errorf("failed to validate FAQ access: %v", aclErr)}
+				} else {
+					results["faq:"+id] = &docInfo{err: fmt.Errorf("FAQ entry %s is not accessible", id)}
+				}
+				mu.Unlock()
+				return
+			}
 			var meta *types.FAQChunkMetadata
 			if chunk.ChunkType == types.ChunkTypeFAQ {
 				meta, _ = chunk.FAQMetadata()
@@ -201,6 +212,12 @@ func (t *GetDocumentInfoTool) Execute(ctx context.Context, args json.RawMessage)
 				mu.Unlock()
 				return
 			}
+			if !knowledgeReadable(ctx, knowledge) {
+				mu.Lock()
+				results[id] = &docInfo{err: fmt.Errorf("document %s is not accessible", knowledge.ID)}
+				mu.Unlock()
+				return
+			}
 
 			// Use knowledge's actual tenant_id for chunk query (supports cross-tenant shared KB).
 			// Keep chunk-type filter aligned with list_knowledge_chunks so the
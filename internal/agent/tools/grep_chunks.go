@@ -13,6 +13,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/searchutil"
 	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"gorm.io/gorm"
 )
 
@@ -65,20 +66,26 @@ type GrepChunksInput struct {
 // the snippet, mirroring the UX of wiki_search.
 type GrepChunksTool struct {
 	BaseTool
-	db            *gorm.DB
-	searchTargets types.SearchTargets
+	db               *gorm.DB
+	knowledgeService interfaces.KnowledgeService
+	searchTargets    types.SearchTargets
 
 	mu         sync.Mutex
 	seenChunks map[string]bool
 }
 
 // NewGrepChunksTool creates a new grep chunks tool
-func NewGrepChunksTool(db *gorm.DB, searchTargets types.SearchTargets) *GrepChunksTool {
+func NewGrepChunksTool(
+	db *gorm.DB,
+	knowledgeService interfaces.KnowledgeService,
+	searchTargets types.SearchTargets,
+) *GrepChunksTool {
 	return &GrepChunksTool{
-		BaseTool:      grepChunksTool,
-		db:            db,
-		searchTargets: searchTargets,
-		seenChunks:    make(map[string]bool),
+		BaseTool:         grepChunksTool,
+		db:               db,
+		knowledgeService: knowledgeService,
+		searchTargets:    searchTargets,
+		seenChunks:       make(map[string]bool),
 	}
 }
 
@@ -136,7 +143,19 @@ func (t *GrepChunksTool) Execute(ctx context.Context, args json.RawMessage) (*ty
 	logger.Infof(ctx, "[Tool][GrepChunks] Queries: %v, Limit: %d, fullKBs: %d, knowledgeIDs: %d, tagScopes: %d",
 		queries, limit, len(fullKBIDs), len(knowledgeIDs), len(tagTargets))
 
-	results, err := t.searchChunks(ctx, queries, fullKBIDs, knowledgeIDs, tagTargets, kbTenantMap)
+	// Documents whose ACL the caller does not match are excluded in SQL so
+	// they never take a slot of the fetch limit.
+	deniedKnowledgeIDs, err := aclDeniedKnowledgeIDs(ctx, t.searchTargets.GetAllKnowledgeBaseIDs(),
+		t.knowledgeService.GetRepository().ListACLKnowledge)
+	if err != nil {
+		logger.Errorf(ctx, "[Tool][GrepChunks] Failed to load document ACLs: %v", err)
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Failed to load document ACLs: %v", err),
+		}, err
+	}
+
+	results, err := t.searchChunks(ctx, queries, fullKBIDs, knowledgeIDs, tagTargets, kbTenantMap, deniedKnowledgeIDs)
 	if err != nil {
 		logger.Errorf(ctx, "[Tool][GrepChunks] Search failed: %v", err)
 		return &types.ToolResult{
@@ -369,6 +388,7 @@ func (t *GrepChunksTool) searchChunks(
 	knowledgeIDs []string,
 	tagTargets []*types.SearchTarget,
 	kbTenantMap map[string]uint64,
+	deniedKnowledgeIDs []string,
 ) ([]chunkWithTitle, error) {
 	if len(kbIDs) == 0 && len(knowledgeIDs) == 0 && len(tagTargets) == 0 {
 		logger.Warnf(ctx, "[Tool][GrepChunks] No kbIDs, knowledgeIDs, or tag scopes specified, returning empty results")
@@ -397,6 +417,9 @@ func (t *GrepChunksTool) searchChunks(
 	logger.Infof(ctx, "[Tool][GrepChunks] Scope: %d knowledge IDs, %d tag scopes, %d KBs",
 		len(knowledgeIDs), len(tagTargets), len(kbIDs))
 	query = query.Where(scopeSQL, scopeArgs...)
+	if len(deniedKnowledgeIDs) > 0 {
+		query = query.Where("chunks.knowledge_id NOT IN ?", deniedKnowledgeIDs)
+	}
 
 	// For MySQL/SQLite REGEXP case-insensitivity we rely on the column's default
 	// collation (utf8mb4_general_ci etc.) OR the driver's REGEXP implementation,
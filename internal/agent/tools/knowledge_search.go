@@ -72,6 +72,7 @@ Avoid:
 - queries (required): 1–5 semantic questions or conceptual statements.
   These should reflect the meaning or topic you want embeddings to capture.
 - knowledge_base_ids (optional): limit the search scope.
+- knowledge_ids (optional): limit the search to specific documents, e.g. ones found by an earlier search.
 
 ## Output
 Returns chunks ranked by semantic similarity, reranked when applicable.  
@@ -96,6 +97,15 @@ Results represent conceptual relevance, not literal keyword overlap.`,
       },
       "minItems": 0,
       "maxItems": 10
+    },
+    "knowledge_ids": {
+      "type": "array",
+      "description": "Optional: document (knowledge) IDs to search within",
+      "items": {
+        "type": "string"
+      },
+      "minItems": 0,
+      "maxItems": 20
     }
   },
   "required": ["queries"]
@@ -106,6 +116,7 @@ Results represent conceptual relevance, not literal keyword overlap.`,
 type KnowledgeSearchInput struct {
 	Queries          []string `json:"queries"`
 	KnowledgeBaseIDs []string `json:"knowledge_base_ids,omitempty"`
+	KnowledgeIDs     []string `json:"knowledge_ids,omitempty"`
 }
 
 // searchResultWithMeta wraps search result with metadata about which query matched it
@@ -200,6 +211,18 @@ func (t *KnowledgeSearchTool) Execute(ctx context.Context, args json.RawMessage)
 		searchTargets = filteredTargets
 	}
 
+	// Optionally narrow further to specific documents within the scope
+	if len(input.KnowledgeIDs) > 0 && len(searchTargets) > 0 {
+		searchTargets = t.knowledgeScopedTargets(ctx, searchTargets, input.KnowledgeIDs)
+		if len(searchTargets) == 0 {
+			return &types.ToolResult{
+				Success: false,
+				Error:   "none of the given knowledge_ids is within the search scope",
+			}, fmt.Errorf("no knowledge_ids in search scope")
+		}
+		logger.Infof(ctx, "[Tool][KnowledgeSearch] Narrowed search to %d documents", len(input.KnowledgeIDs))
+	}
+
 	// Validate search targets
 	if len(searchTargets) == 0 {
 		logger.Errorf(ctx, "[Tool][KnowledgeSearch] No search targets available")
@@ -272,7 +295,8 @@ func (t *KnowledgeSearchTool) Execute(ctx context.Context, args json.RawMessage)
 		len(searchTargets))
 	kbTypeMap := t.getKnowledgeBaseTypes(ctx, kbIDs)
 
-	allResults := t.concurrentSearchByTargets(ctx, queries, searchTargets,
+	searchCtx, statusRecorder := types.WithRetrievalStatusRecorder(ctx)
+	allResults := t.concurrentSearchByTargets(searchCtx, queries, searchTargets,
 		topK, vectorThreshold, keywordThreshold, kbTypeMap)
 	logger.Infof(ctx, "[Tool][KnowledgeSearch] Concurrent search completed: %d raw results", len(allResults))
 
@@ -392,10 +416,88 @@ func (t *KnowledgeSearchTool) Execute(ctx context.Context, args json.RawMessage)
 		logger.Errorf(ctx, "[Tool][KnowledgeSearch] Failed to format output: %v", err)
 		return result, err
 	}
+	if status := statusRecorder.Status(); status.Degraded {
+		annotateDegradedRetrieval(result, status)
+	}
 	logger.Infof(ctx, "[Tool][KnowledgeSearch] Output: %s", result.Output)
 	return result, nil
 }
 
+// knowledgeScopedTargets returns search targets covering only the given
+// documents, dropping those that do not exist or are outside scope.
+func (t *KnowledgeSearchTool) knowledgeScopedTargets(
+	ctx context.Context, scope types.SearchTargets, knowledgeIDs []string,
+) types.SearchTargets {
+	var docs []scopedKnowledge
+	for _, id := range dedupNonEmptyStrings(knowledgeIDs) {
+		knowledge, err := t.knowledgeService.GetKnowledgeByIDOnly(ctx, id)
+		if err != nil || knowledge == nil {
+			logger.Warnf(ctx, "[Tool][KnowledgeSearch] Skipping unknown knowledge %s: %v", id, err)
+			continue
+		}
+		if !scope.ContainsKB(knowledge.KnowledgeBaseID) {
+			continue
+		}
+		allowed, err := searchTargetsAllowKnowledgeID(ctx, scope, knowledge.ID, knowledge.KnowledgeBaseID, t.knowledgeService)
+		if err != nil || !allowed {
+			continue
+		}
+		docs = append(docs, scopedKnowledge{knowledgeID: knowledge.ID, knowledgeBaseID: knowledge.KnowledgeBaseID})
+	}
+	return buildKnowledgeTargets(scope, docs)
+}
+
+// scopedKnowledge is a document the search may be narrowed to.
+type scopedKnowledge struct {
+	knowledgeID     string
+	knowledgeBaseID string
+}
+
+// buildKnowledgeTargets groups docs into one knowledge target per knowledge
+// base, in first-seen order, keeping the tenant of the scope's target.
+func buildKnowledgeTargets(scope types.SearchTargets, docs []scopedKnowledge) types.SearchTargets {
+	var targets types.SearchTargets
+	byKB := make(map[string]*types.SearchTarget)
+	for _, d := range docs {
+		target, ok := byKB[d.knowledgeBaseID]
+		if !ok {
+			target = &types.SearchTarget{
+				Type:            types.SearchTargetTypeKnowledge,
+				KnowledgeBaseID: d.knowledgeBaseID,
+				TenantID:        scope.GetTenantIDForKB(d.knowledgeBaseID),
+			}
+			byKB[d.knowledgeBaseID] = target
+			targets = append(targets, target)
+		}
+		target.KnowledgeIDs = append(target.KnowledgeIDs, d.knowledgeID)
+	}
+	return targets
+}
+
+// annotateDegradedRetrieval tells the model that the knowledge base could
+// not be searched normally, so "no results" is not read as "the knowledge
+// base has nothing on this topic".
+func annotateDegradedRetrieval(result *types.ToolResult, status types.RetrievalStatus) {
+	if result == nil {
+		return
+	}
+	if result.Data == nil {
+		result.Data = make(map[string]interface{})
+	}
+	result.Data["retrieval_status"] = status
+
+	var note string
+	if status.Unavailable() {
+		note = "=== ⚠️ Retrieval unavailable ===\n" +
+			"The knowledge base search service is temporarily unavailable; the results below are NOT a complete search.\n" +
+			"- Tell the user that knowledge base retrieval is currently unavailable instead of claiming nothing was found\n\n"
+	} else {
+		note = "=== ⚠️ Degraded retrieval ===\n" +
+			"Vector search is temporarily unavailable; only keyword matches were searched, so recall may be incomplete.\n\n"
+	}
+	result.Output = note + result.Output
+}
+
 // getKnowledgeBaseTypes fetches knowledge base types for the given IDs
 func (t *KnowledgeSearchTool) getKnowledgeBaseTypes(ctx context.Context, kbIDs []string) map[string]string {
 	kbTypeMap := make(map[string]string, len(kbIDs))
@@ -512,7 +614,7 @@ func (t *KnowledgeSearchTool) concurrentSearchByTargets(
 				var fullKBIDs []string
 				var knowledgeTargets []*types.SearchTarget
 				for _, st := range targets {
-					if st.Type == types.SearchTargetTypeKnowledgeBase && len(st.TagIDs) == 0 {
+					if st.IsWholeKnowledgeBase() {
 						fullKBIDs = append(fullKBIDs, st.KnowledgeBaseID)
 					} else {
 						knowledgeTargets = append(knowledgeTargets, st)
@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestBuildKnowledgeTargets_GroupsDocsByKB(t *testing.T) {
+	scope := types.SearchTargets{
+		{Type: types.SearchTargetTypeKnowledgeBase, KnowledgeBaseID: "kb-1", TenantID: 7},
+		{Type: types.SearchTargetTypeKnowledgeBase, KnowledgeBaseID: "kb-2", TenantID: 9, TagIDs: []string{"tag-a"}},
+	}
+	targets := buildKnowledgeTargets(scope, []scopedKnowledge{
+		{knowledgeID: "doc-2", knowledgeBaseID: "kb-2"},
+		{knowledgeID: "doc-1", knowledgeBaseID: "kb-1"},
+		{knowledgeID: "doc-3", knowledgeBaseID: "kb-2"},
+	})
+
+	if len(targets) != 2 {
+		t.Fatalf("expected one target per KB, got %d", len(targets))
+	}
+	first, second := targets[0], targets[1]
+	if first.KnowledgeBaseID != "kb-2" || first.TenantID != 9 || first.Type != types.SearchTargetTypeKnowledge {
+		t.Fatalf("unexpected first target: %+v", first)
+	}
+	if len(first.KnowledgeIDs) != 2 || first.KnowledgeIDs[0] != "doc-2" || first.KnowledgeIDs[1] != "doc-3" {
+		t.Fatalf("unexpected knowledge IDs: %v", first.KnowledgeIDs)
+	}
+	if len(first.TagIDs) != 0 {
+		t.Fatalf("documents are already authorized, tags must not narrow further: %v", first.TagIDs)
+	}
+	if second.KnowledgeBaseID != "kb-1" || second.TenantID != 7 || len(second.KnowledgeIDs) != 1 {
+		t.Fatalf("unexpected second target: %+v", second)
+	}
+}
+
+func TestBuildKnowledgeTargets_NoDocs(t *testing.T) {
+	if targets := buildKnowledgeTargets(types.SearchTargets{{KnowledgeBaseID: "kb-1"}}, nil); len(targets) != 0 {
+		t.Fatalf("expected no targets, got %v", targets)
+	}
+}
@@ -146,6 +146,12 @@ func (t *ListKnowledgeChunksTool) Execute(ctx context.Context, args json.RawMess
 			Error:   fmt.Sprintf("Knowledge %s is not within the current @mention scope", knowledge.ID),
 		}, fmt.Errorf("knowledge not in search target scope")
 	}
+	if !knowledgeReadable(ctx, knowledge) {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("Knowledge %s is not accessible", knowledge.ID),
+		}, fmt.Errorf("knowledge denied by its ACL")
+	}
 
 	// Use the knowledge's actual tenant_id for chunk query (supports cross-tenant shared KB)
 	effectiveTenantID := knowledge.TenantID
@@ -322,6 +328,19 @@ func (t *ListKnowledgeChunksTool) executeByChunkID(ctx context.Context, chunkID
 			Error:   fmt.Sprintf("chunk %s is not within the current @mention scope", chunk.ID),
 		}, fmt.Errorf("chunk not in search target scope")
 	}
+	readable, aclErr := chunkReadable(ctx, chunk, t.knowledgeService)
+	if aclErr != nil {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("failed to validate chunk access: %v", aclErr),
+		}, aclErr
+	}
+	if !readable {
+		return &types.ToolResult{
+			Success: false,
+			Error:   fmt.Sprintf("chunk %s is not accessible", chunk.ID),
+		}, fmt.Errorf("chunk denied by its document ACL")
+	}
 
 	chunks := []*types.Chunk{chunk}
 	if chunk.ImageInfo == "" {
@@ -9,6 +9,47 @@ import (
 
 type knowledgeTagsFetcher func(context.Context, []string) (map[string][]*types.KnowledgeTag, error)
 
+type aclKnowledgeLister func(context.Context, []string) ([]*types.Knowledge, error)
+
+// knowledgeReadable reports whether the caller in ctx passes the document ACL
+// of the knowledge.
+func knowledgeReadable(ctx context.Context, knowledge *types.Knowledge) bool {
+	return knowledge.ReadableBy(types.RetrievalPrincipalFromContext(ctx))
+}
+
+// chunkReadable loads the document of the chunk and applies its ACL.
+func chunkReadable(
+	ctx context.Context,
+	chunk *types.Chunk,
+	knowledgeService interfaces.KnowledgeService,
+) (bool, error) {
+	knowledge, err := knowledgeService.GetKnowledgeByIDOnly(ctx, chunk.KnowledgeID)
+	if err != nil {
+		return false, err
+	}
+	return knowledgeReadable(ctx, knowledge), nil
+}
+
+// aclDeniedKnowledgeIDs returns the documents of the knowledge bases whose ACL
+// the caller in ctx does not match.
+func aclDeniedKnowledgeIDs(ctx context.Context, kbIDs []string, listACLKnowledge aclKnowledgeLister) ([]string, error) {
+	if len(kbIDs) == 0 {
+		return nil, nil
+	}
+	restricted, err := listACLKnowledge(ctx, kbIDs)
+	if err != nil {
+		return nil, err
+	}
+	principal := types.RetrievalPrincipalFromContext(ctx)
+	var denied []string
+	for _, knowledge := range restricted {
+		if !knowledge.ReadableBy(principal) {
+			denied = append(denied, knowledge.ID)
+		}
+	}
+	return denied, nil
+}
+
 func searchTargetsAllowKnowledgeID(
 	ctx context.Context,
 	searchTargets types.SearchTargets,
@@ -27,7 +68,7 @@ func searchTargetsAllowKnowledgeID(
 			continue
 		}
 		matchedKB = true
-		if target.Type == types.SearchTargetTypeKnowledgeBase && len(target.TagIDs) == 0 {
+		if target.IsWholeKnowledgeBase() {
 			return true, nil
 		}
 		for _, allowedID := range target.KnowledgeIDs {
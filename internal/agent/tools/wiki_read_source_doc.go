@@ -133,6 +133,9 @@ func (t *wikiReadSourceDocTool) Execute(ctx context.Context, args json.RawMessag
 	if err != nil {
 		return &types.ToolResult{Success: false, Error: fmt.Sprintf("Document not found: %v", err)}, nil
 	}
+	if !knowledgeReadable(ctx, knowledge) {
+		return &types.ToolResult{Success: false, Error: fmt.Sprintf("Document %s is not accessible", knowledgeID)}, nil
+	}
 
 	var sb strings.Builder
 	sb.WriteString("<source_document>\n<metadata>\n")
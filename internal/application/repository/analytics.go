@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// analyticsRepository implements the AnalyticsRepository interface
+type analyticsRepository struct {
+	db *gorm.DB
+}
+
+// NewAnalyticsRepository creates a new query analytics repository
+func NewAnalyticsRepository(db *gorm.DB) interfaces.AnalyticsRepository {
+	return &analyticsRepository{db: db}
+}
+
+// queryLogsQuery selects the query logs, aliased as q, matching a query;
+// only those that searched the query's knowledge base when it is set
+func (r *analyticsRepository) queryLogsQuery(ctx context.Context, q *types.AnalyticsQuery) *gorm.DB {
+	db := r.db.WithContext(ctx).Table("query_logs AS q").Where("q.tenant_id = ?", q.TenantID)
+	if q.StartDay != "" {
+		db = db.Where("q.day >= ?", q.StartDay)
+	}
+	if q.EndDay != "" {
+		db = db.Where("q.day <= ?", q.EndDay)
+	}
+	if q.KnowledgeBaseID != "" {
+		db = db.Where("q.id IN (?)", r.db.Model(&types.QueryLogKnowledgeBase{}).
+			Select("query_log_id").Where("knowledge_base_id = ?", q.KnowledgeBaseID))
+	}
+	return db
+}
+
+// scopeQueryLogChild filters a table of query log children, aliased as
+// alias, by a query
+func scopeQueryLogChild(db *gorm.DB, alias string, q *types.AnalyticsQuery) *gorm.DB {
+	db = db.Where(alias+".tenant_id = ?", q.TenantID)
+	if q.StartDay != "" {
+		db = db.Where(alias+".day >= ?", q.StartDay)
+	}
+	if q.EndDay != "" {
+		db = db.Where(alias+".day <= ?", q.EndDay)
+	}
+	if q.KnowledgeBaseID != "" {
+		db = db.Where(alias+".knowledge_base_id = ?", q.KnowledgeBaseID)
+	}
+	return db
+}
+
+// SaveQueryLog stores a query log with its knowledge bases and documents
+func (r *analyticsRepository) SaveQueryLog(ctx context.Context, log *types.QueryLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(log).Error; err != nil {
+			return err
+		}
+		for _, kb := range log.KnowledgeBases {
+			kb.QueryLogID = log.ID
+		}
+		for _, doc := range log.Documents {
+			doc.QueryLogID = log.ID
+		}
+		if len(log.KnowledgeBases) > 0 {
+			if err := tx.CreateInBatches(log.KnowledgeBases, 100).Error; err != nil {
+				return err
+			}
+		}
+		if len(log.Documents) > 0 {
+			return tx.CreateInBatches(log.Documents, 100).Error
+		}
+		return nil
+	})
+}
+
+// CountByDay counts the queries per day, in day order
+func (r *analyticsRepository) CountByDay(
+	ctx context.Context, query *types.AnalyticsQuery,
+) ([]*types.QueryDayCounts, error) {
+	var days []*types.QueryDayCounts
+	if err := r.queryLogsQuery(ctx, query).
+		Select("q.day, COUNT(*) AS queries, " +
+			"COALESCE(SUM(CASE WHEN q.searched THEN 1 ELSE 0 END), 0) AS searched, " +
+			"COALESCE(SUM(CASE WHEN q.zero_result THEN 1 ELSE 0 END), 0) AS zero_results, " +
+			"COALESCE(SUM(q.latency_ms), 0) AS latency_sum").
+		Group("q.day").
+		Order("q.day ASC").
+		Scan(&days).Error; err != nil {
+		return nil, err
+	}
+	return days, nil
+}
+
+// LatencyPercentiles returns the answer latency at each percentile, by the
+// nearest-rank method. It returns nil when no query matches.
+func (r *analyticsRepository) LatencyPercentiles(
+	ctx context.Context, query *types.AnalyticsQuery, percentiles []int,
+) ([]*types.LatencyPercentile, error) {
+	var total int64
+	if err := r.queryLogsQuery(ctx, query).Count(&total).Error; err != nil {
+		return nil, err
+	}
+	if total == 0 {
+		return nil, nil
+	}
+	result := make([]*types.LatencyPercentile, 0, len(percentiles))
+	for _, p := range percentiles {
+		// The nearest rank is ceil(p/100 * total), 1-based
+		rank := (int64(p)*total + 99) / 100
+		if rank < 1 {
+			rank = 1
+		}
+		var latencies []int64
+		if err := r.queryLogsQuery(ctx, query).
+			Order("q.latency_ms ASC").
+			Offset(int(rank-1)).
+			Limit(1).
+			Pluck("q.latency_ms", &latencies).Error; err != nil {
+			return nil, err
+		}
+		percentile := &types.LatencyPercentile{Percentile: p}
+		if len(latencies) > 0 {
+			percentile.LatencyMs = latencies[0]
+		}
+		result = append(result, percentile)
+	}
+	return result, nil
+}
+
+// TopQueries returns the most asked queries, or those that most often
+// retrieved nothing when zeroResultOnly is set
+func (r *analyticsRepository) TopQueries(
+	ctx context.Context, query *types.AnalyticsQuery, zeroResultOnly bool,
+) ([]*types.QueryStat, error) {
+	db := r.queryLogsQuery(ctx, query)
+	if zeroResultOnly {
+		db = db.Where("q.zero_result = ?", true)
+	}
+	var stats []*types.QueryStat
+	if err := db.
+		Select("MAX(q.query) AS query, COUNT(*) AS count, " +
+			"COALESCE(SUM(CASE WHEN q.zero_result THEN 1 ELSE 0 END), 0) AS zero_results, " +
+			"CAST(COALESCE(SUM(q.latency_ms), 0) / COUNT(*) AS BIGINT) AS avg_latency_ms, MAX(q.day) AS last_day").
+		Group("q.query_hash").
+		Order("count DESC, last_day DESC, query ASC").
+		Limit(query.Limit).
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// TopDocuments returns the documents answers cited most, then retrieved most
+func (r *analyticsRepository) TopDocuments(
+	ctx context.Context, query *types.AnalyticsQuery,
+) ([]*types.DocumentStat, error) {
+	var stats []*types.DocumentStat
+	if err := scopeQueryLogChild(r.db.WithContext(ctx).Table("query_log_documents AS d"), "d", query).
+		Select("d.knowledge_id, MAX(d.knowledge_base_id) AS knowledge_base_id, " +
+			"MAX(d.knowledge_title) AS knowledge_title, " +
+			"COALESCE(SUM(CASE WHEN d.cited THEN 1 ELSE 0 END), 0) AS citations, COUNT(*) AS retrievals").
+		Group("d.knowledge_id").
+		Order("citations DESC, retrievals DESC, d.knowledge_id ASC").
+		Limit(query.Limit).
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// knowledgeBaseDayRow is a row of the per-knowledge-base, per-day counts
+type knowledgeBaseDayRow struct {
+	KnowledgeBaseID string
+	Day             string
+	Queries         int64
+	ZeroResults     int64
+}
+
+// CountByKnowledgeBase counts the queries per knowledge base and day, most
+// used knowledge base first
+func (r *analyticsRepository) CountByKnowledgeBase(
+	ctx context.Context, query *types.AnalyticsQuery,
+) ([]*types.KnowledgeBaseUsage, error) {
+	var rows []knowledgeBaseDayRow
+	if err := scopeQueryLogChild(r.db.WithContext(ctx).Table("query_log_knowledge_bases AS k"), "k", query).
+		Select("k.knowledge_base_id, k.day, COUNT(*) AS queries, " +
+			"COALESCE(SUM(CASE WHEN k.zero_result THEN 1 ELSE 0 END), 0) AS zero_results").
+		Group("k.knowledge_base_id, k.day").
+		Order("k.knowledge_base_id ASC, k.day ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	var usage []*types.KnowledgeBaseUsage
+	byID := make(map[string]*types.KnowledgeBaseUsage)
+	for _, row := range rows {
+		kb := byID[row.KnowledgeBaseID]
+		if kb == nil {
+			kb = &types.KnowledgeBaseUsage{KnowledgeBaseID: row.KnowledgeBaseID}
+			byID[row.KnowledgeBaseID] = kb
+			usage = append(usage, kb)
+		}
+		kb.Queries += row.Queries
+		kb.ZeroResults += row.ZeroResults
+		kb.Days = append(kb.Days, &types.KnowledgeBaseDayCounts{
+			Day: row.Day, Queries: row.Queries, ZeroResults: row.ZeroResults,
+		})
+	}
+	sort.SliceStable(usage, func(i, j int) bool {
+		return usage[i].Queries > usage[j].Queries
+	})
+	return usage, nil
+}
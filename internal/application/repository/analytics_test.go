@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupAnalyticsTestDB(t *testing.T) *analyticsRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&types.QueryLog{}, &types.QueryLogKnowledgeBase{}, &types.QueryLogDocument{}))
+	return &analyticsRepository{db: db}
+}
+
+func saveTestQueryLog(
+	t *testing.T, repo *analyticsRepository, id string, tenantID uint64, day, query string,
+	latency time.Duration, refs types.References, citations types.Citations,
+) {
+	t.Helper()
+	answeredAt, err := time.Parse(types.TokenUsageDayLayout, day)
+	require.NoError(t, err)
+	log := types.NewQueryLog(tenantID, "u1", &types.QueryRecord{
+		SessionID: "s-" + id, MessageID: "m-" + id, Mode: types.QueryLogModeQuick, Query: query,
+		KnowledgeBaseIDs: []string{"kb1"}, References: refs, Citations: citations,
+		ReceivedAt: answeredAt.Add(-latency), AnsweredAt: answeredAt,
+	})
+	log.ID = id
+	require.NoError(t, repo.SaveQueryLog(context.Background(), log))
+}
+
+func TestAnalyticsRepository_Aggregates(t *testing.T) {
+	repo := setupAnalyticsTestDB(t)
+	ctx := context.Background()
+
+	doc1 := &types.SearchResult{ID: "c1", KnowledgeID: "k1", KnowledgeBaseID: "kb1", KnowledgeTitle: "Handbook"}
+	doc1b := &types.SearchResult{ID: "c2", KnowledgeID: "k1", KnowledgeBaseID: "kb1", KnowledgeTitle: "Handbook"}
+	doc2 := &types.SearchResult{ID: "c3", KnowledgeID: "k2", KnowledgeBaseID: "kb2", KnowledgeTitle: "Policy"}
+	cite1 := types.Citations{{Index: 1, ChunkID: "c1", KnowledgeID: "k1"}}
+
+	saveTestQueryLog(t, repo, "q1", 1, "2026-10-01", "How many leave days?", 100*time.Millisecond,
+		types.References{doc1, doc1b, doc2}, cite1)
+	saveTestQueryLog(t, repo, "q2", 1, "2026-10-01", "how many leave days", 300*time.Millisecond,
+		types.References{doc1}, cite1)
+	saveTestQueryLog(t, repo, "q3", 1, "2026-10-02", "Where is the office?", 200*time.Millisecond, nil, nil)
+	saveTestQueryLog(t, repo, "q4", 1, "2026-10-03", "Where is the office", 400*time.Millisecond, nil, nil)
+	saveTestQueryLog(t, repo, "q5", 1, "2026-10-03", "Parking?", 1000*time.Millisecond,
+		types.References{doc2}, nil)
+	saveTestQueryLog(t, repo, "other", 2, "2026-10-01", "How many leave days?", time.Second,
+		types.References{doc1}, cite1)
+
+	q := &types.AnalyticsQuery{TenantID: 1, StartDay: "2026-10-01", EndDay: "2026-10-03", Limit: 10}
+
+	days, err := repo.CountByDay(ctx, q)
+	require.NoError(t, err)
+	overview := types.NewQueryAnalyticsOverview(q.StartDay, q.EndDay, days)
+	assert.Equal(t, int64(5), overview.Queries)
+	assert.Equal(t, int64(5), overview.Searched)
+	assert.Equal(t, int64(2), overview.ZeroResults)
+	assert.InDelta(t, 0.4, overview.ZeroResultRate, 1e-9)
+	assert.Equal(t, int64(400), overview.AvgLatencyMs)
+	require.Len(t, overview.Days, 3)
+	assert.Equal(t, "2026-10-01", overview.Days[0].Day)
+	assert.Equal(t, int64(200), overview.Days[0].AvgLatencyMs)
+
+	percentiles, err := repo.LatencyPercentiles(ctx, q, []int{50, 90, 99})
+	require.NoError(t, err)
+	require.Len(t, percentiles, 3)
+	assert.Equal(t, int64(300), percentiles[0].LatencyMs)
+	assert.Equal(t, int64(1000), percentiles[1].LatencyMs)
+	assert.Equal(t, int64(1000), percentiles[2].LatencyMs)
+
+	top, err := repo.TopQueries(ctx, q, false)
+	require.NoError(t, err)
+	require.Len(t, top, 3)
+	assert.Equal(t, int64(2), top[0].Count)
+	assert.Equal(t, "2026-10-03", top[0].LastDay, "the most recent of the ties comes first")
+	assert.Equal(t, int64(300), top[0].AvgLatencyMs)
+	assert.Equal(t, int64(2), top[0].ZeroResults)
+
+	zero, err := repo.TopQueries(ctx, q, true)
+	require.NoError(t, err)
+	require.Len(t, zero, 1)
+	assert.Contains(t, zero[0].Query, "office")
+
+	docs, err := repo.TopDocuments(ctx, q)
+	require.NoError(t, err)
+	require.Len(t, docs, 2)
+	assert.Equal(t, "k1", docs[0].KnowledgeID)
+	assert.Equal(t, int64(2), docs[0].Citations)
+	assert.Equal(t, int64(2), docs[0].Retrievals)
+	assert.Equal(t, "k2", docs[1].KnowledgeID)
+	assert.Equal(t, int64(0), docs[1].Citations)
+	assert.Equal(t, int64(2), docs[1].Retrievals)
+
+	usage, err := repo.CountByKnowledgeBase(ctx, q)
+	require.NoError(t, err)
+	require.Len(t, usage, 2)
+	assert.Equal(t, "kb1", usage[0].KnowledgeBaseID)
+	assert.Equal(t, int64(5), usage[0].Queries)
+	assert.Equal(t, int64(2), usage[0].ZeroResults)
+	require.Len(t, usage[0].Days, 3)
+	assert.Equal(t, "kb2", usage[1].KnowledgeBaseID)
+	assert.Equal(t, int64(2), usage[1].Queries)
+
+	kb2 := *q
+	kb2.KnowledgeBaseID = "kb2"
+	days, err = repo.CountByDay(ctx, &kb2)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), types.NewQueryAnalyticsOverview("", "", days).Queries)
+	docs, err = repo.TopDocuments(ctx, &kb2)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	assert.Equal(t, "k2", docs[0].KnowledgeID)
+
+	empty := &types.AnalyticsQuery{TenantID: 3, Limit: 10}
+	percentiles, err = repo.LatencyPercentiles(ctx, empty, types.AnalyticsLatencyPercentiles)
+	require.NoError(t, err)
+	assert.Nil(t, percentiles)
+}
+
+func TestAnalyticsRepository_TopQueriesLimit(t *testing.T) {
+	repo := setupAnalyticsTestDB(t)
+	for i := 0; i < 5; i++ {
+		saveTestQueryLog(t, repo, fmt.Sprintf("q%d", i), 1, "2026-10-01", fmt.Sprintf("question %d", i),
+			time.Second, nil, nil)
+	}
+	top, err := repo.TopQueries(context.Background(), &types.AnalyticsQuery{TenantID: 1, Limit: 2}, false)
+	require.NoError(t, err)
+	assert.Len(t, top, 2)
+}
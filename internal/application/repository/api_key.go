@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// apiKeyRepository implements the APIKeyRepository interface
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *gorm.DB) interfaces.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create creates an API key
+func (r *apiKeyRepository) Create(ctx context.Context, key *types.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// GetByID gets an API key of a tenant
+func (r *apiKeyRepository) GetByID(ctx context.Context, tenantID uint64, id string) (*types.APIKey, error) {
+	var key types.APIKey
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByHash gets the API key whose secret has the hash
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*types.APIKey, error) {
+	var key types.APIKey
+	if err := r.db.WithContext(ctx).Where("key_hash = ?", hash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// ListByTenant lists the API keys of a tenant, newest first
+func (r *apiKeyRepository) ListByTenant(ctx context.Context, tenantID uint64) ([]*types.APIKey, error) {
+	var keys []*types.APIKey
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// Update saves the settings and secret hash of an API key
+func (r *apiKeyRepository) Update(ctx context.Context, key *types.APIKey) error {
+	return r.db.WithContext(ctx).Model(&types.APIKey{}).
+		Where("tenant_id = ? AND id = ?", key.TenantID, key.ID).
+		Select("name", "key_prefix", "key_hash", "role", "scopes", "knowledge_base_ids", "expires_at", "updated_at").
+		Updates(key).Error
+}
+
+// TouchLastUsed records when an API key was last used. It leaves updated_at
+// alone, which tracks changes to the key's settings.
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&types.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", at).Error
+}
+
+// Delete revokes an API key of a tenant
+func (r *apiKeyRepository) Delete(ctx context.Context, tenantID uint64, id string) error {
+	result := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Delete(&types.APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const apiKeyTestDDL = `
+CREATE TABLE api_keys (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    key_prefix VARCHAR(16) NOT NULL DEFAULT '',
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    role VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    scopes TEXT,
+    knowledge_base_ids TEXT,
+    expires_at DATETIME,
+    last_used_at DATETIME,
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+`
+
+func setupAPIKeyTestDB(t *testing.T) *apiKeyRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(apiKeyTestDDL).Error)
+	return &apiKeyRepository{db: db}
+}
+
+func newTestAPIKey(tenantID uint64, secret string) *types.APIKey {
+	return &types.APIKey{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		Name:      "ci",
+		KeyPrefix: secret[:7],
+		KeyHash:   types.HashAPIKeySecret(secret),
+		Role:      types.TenantRoleViewer,
+		Scopes:    types.StringArray{types.APIKeyScopeChat},
+	}
+}
+
+func TestAPIKey_CreateLookupAndRevoke(t *testing.T) {
+	repo := setupAPIKeyTestDB(t)
+	ctx := context.Background()
+
+	key := newTestAPIKey(1, "wk-first-secret")
+	key.KnowledgeBaseIDs = types.StringArray{"kb-1"}
+	require.NoError(t, repo.Create(ctx, key))
+	require.NoError(t, repo.Create(ctx, newTestAPIKey(2, "wk-other-tenant")))
+
+	got, err := repo.GetByHash(ctx, types.HashAPIKeySecret("wk-first-secret"))
+	require.NoError(t, err)
+	assert.Equal(t, key.ID, got.ID)
+	assert.Equal(t, types.StringArray{"kb-1"}, got.KnowledgeBaseIDs)
+
+	_, err = repo.GetByID(ctx, 2, key.ID)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "keys of another tenant are invisible")
+
+	keys, err := repo.ListByTenant(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	require.NoError(t, repo.Delete(ctx, 1, key.ID))
+	_, err = repo.GetByHash(ctx, key.KeyHash)
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound), "a revoked key no longer authenticates")
+	assert.True(t, errors.Is(repo.Delete(ctx, 1, key.ID), gorm.ErrRecordNotFound))
+}
+
+func TestAPIKey_UpdateRotatesSecretAndTouch(t *testing.T) {
+	repo := setupAPIKeyTestDB(t)
+	ctx := context.Background()
+
+	key := newTestAPIKey(1, "wk-old-secret")
+	require.NoError(t, repo.Create(ctx, key))
+
+	key.KeyHash = types.HashAPIKeySecret("wk-new-secret")
+	key.Scopes = types.StringArray{types.APIKeyScopeIngest}
+	require.NoError(t, repo.Update(ctx, key))
+
+	_, err := repo.GetByHash(ctx, types.HashAPIKeySecret("wk-old-secret"))
+	assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	got, err := repo.GetByHash(ctx, types.HashAPIKeySecret("wk-new-secret"))
+	require.NoError(t, err)
+	assert.Equal(t, types.StringArray{types.APIKeyScopeIngest}, got.Scopes)
+	assert.Nil(t, got.LastUsedAt)
+
+	usedAt := time.Now().Truncate(time.Second)
+	require.NoError(t, repo.TouchLastUsed(ctx, key.ID, usedAt))
+	got, err = repo.GetByID(ctx, 1, key.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.LastUsedAt)
+	assert.True(t, got.LastUsedAt.Equal(usedAt))
+}
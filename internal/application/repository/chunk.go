@@ -137,6 +137,27 @@ func (r *chunkRepository) ListChunksByKnowledgeID(
 	var chunks []*types.Chunk
 	if err := r.db.WithContext(ctx).
 		Where("tenant_id = ? AND knowledge_id = ? and chunk_type = ?", tenantID, knowledgeID, "text").
+		Scopes(activeIndexGeneration).
+		Order("chunk_index ASC").
+		Find(&chunks).Error; err != nil {
+		return nil, err
+	}
+	return chunks, nil
+}
+
+// ListChunksByKnowledgeIndices lists the enabled text chunks of a knowledge
+// ID at the given chunk indices, ordered by chunk index
+func (r *chunkRepository) ListChunksByKnowledgeIndices(
+	ctx context.Context, tenantID uint64, knowledgeID string, indices []int,
+) ([]*types.Chunk, error) {
+	if len(indices) == 0 {
+		return []*types.Chunk{}, nil
+	}
+	var chunks []*types.Chunk
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_id = ? AND chunk_type = ? AND is_enabled = ? AND chunk_index IN ?",
+			tenantID, knowledgeID, types.ChunkTypeText, true, indices).
+		Scopes(activeIndexGeneration).
 		Order("chunk_index ASC").
 		Find(&chunks).Error; err != nil {
 		return nil, err
@@ -164,6 +185,7 @@ func (r *chunkRepository) ListPagedChunksByKnowledgeID(
 	baseFilter := func(db *gorm.DB) *gorm.DB {
 		db = db.Where("tenant_id = ? AND knowledge_id = ? AND chunk_type IN (?) AND status in (?)",
 			tenantID, knowledgeID, chunkType, []int{int(types.ChunkStatusIndexed), int(types.ChunkStatusDefault)})
+		db = db.Scopes(activeIndexGeneration)
 		if tagID != "" {
 			db = db.Where("tag_id = ?", tagID)
 		}
@@ -291,6 +313,33 @@ func (r *chunkRepository) UpdateChunk(ctx context.Context, chunk *types.Chunk) e
 	return r.db.WithContext(ctx).Omit("SeqID").Save(chunk).Error
 }
 
+// UpdateChunkPositions updates the position of chunks within their
+// document: chunk_index, start_at, end_at, pre_chunk_id, next_chunk_id and
+// updated_at. Used when a new version of a document keeps unchanged chunks.
+func (r *chunkRepository) UpdateChunkPositions(ctx context.Context, tenantID uint64, chunks []*types.Chunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, chunk := range chunks {
+			err := tx.Model(&types.Chunk{}).
+				Where("tenant_id = ? AND id = ?", tenantID, chunk.ID).
+				Updates(map[string]interface{}{
+					"chunk_index":   chunk.ChunkIndex,
+					"start_at":      chunk.StartAt,
+					"end_at":        chunk.EndAt,
+					"pre_chunk_id":  chunk.PreChunkID,
+					"next_chunk_id": chunk.NextChunkID,
+					"updated_at":    chunk.UpdatedAt,
+				}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 // UpdateChunks updates chunks in batch using raw SQL for efficiency.
 // Uses raw SQL to bypass GORM's default value handling for boolean fields.
 //
@@ -466,11 +515,24 @@ func (r *chunkRepository) DeleteByKnowledgeList(ctx context.Context, tenantID ui
 	).Delete(&types.Chunk{}).Error
 }
 
-// MoveChunksByKnowledgeID updates knowledge_base_id for all chunks of a knowledge item
+// MoveChunksByKnowledgeID updates knowledge_base_id for all chunks of a knowledge item.
+// The index generation is reset since generations are numbered per knowledge base.
 func (r *chunkRepository) MoveChunksByKnowledgeID(ctx context.Context, tenantID uint64, knowledgeID string, targetKBID string) error {
 	return r.db.WithContext(ctx).Model(&types.Chunk{}).
 		Where("tenant_id = ? AND knowledge_id = ?", tenantID, knowledgeID).
-		Update("knowledge_base_id", targetKBID).Error
+		Updates(map[string]interface{}{"knowledge_base_id": targetKBID, "index_generation": 0}).Error
+}
+
+// ListChunkIDsByIndexGeneration lists the IDs of the chunks a knowledge base
+// has staged for the given index generation.
+func (r *chunkRepository) ListChunkIDsByIndexGeneration(
+	ctx context.Context, tenantID uint64, kbID string, generation int64,
+) ([]string, error) {
+	var ids []string
+	err := r.db.WithContext(ctx).Model(&types.Chunk{}).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND index_generation = ?", tenantID, kbID, generation).
+		Pluck("id", &ids).Error
+	return ids, err
 }
 
 // DeleteChunksByTagID deletes all chunks with the specified tag ID
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// duplicateReportRepository implements the DuplicateReportRepository interface
+type duplicateReportRepository struct {
+	db *gorm.DB
+}
+
+// NewDuplicateReportRepository creates a new duplicate report repository
+func NewDuplicateReportRepository(db *gorm.DB) interfaces.DuplicateReportRepository {
+	return &duplicateReportRepository{db: db}
+}
+
+// GetReport returns the report of a knowledge base, or nil
+func (r *duplicateReportRepository) GetReport(
+	ctx context.Context, tenantID uint64, knowledgeBaseID string,
+) (*types.DuplicateReport, error) {
+	var report types.DuplicateReport
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, knowledgeBaseID).
+		First(&report).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// SaveReport creates or replaces the report of a knowledge base
+func (r *duplicateReportRepository) SaveReport(ctx context.Context, report *types.DuplicateReport) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "knowledge_base_id"}},
+		UpdateAll: true,
+	}).Create(report).Error
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestDuplicateReportRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&types.DuplicateReport{}))
+	repo := NewDuplicateReportRepository(db)
+	ctx := context.Background()
+
+	got, err := repo.GetReport(ctx, 1, "kb1")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	require.NoError(t, repo.SaveReport(ctx, &types.DuplicateReport{
+		KnowledgeBaseID: "kb1", TenantID: 1, Status: types.DuplicateStatusRunning, Threshold: 0.9,
+	}))
+	require.NoError(t, repo.SaveReport(ctx, &types.DuplicateReport{
+		KnowledgeBaseID: "kb1", TenantID: 1, Status: types.DuplicateStatusCompleted, Threshold: 0.8,
+		ChunkGroups: types.DuplicateGroups{{
+			Kind:       types.DuplicateKindNear,
+			Keep:       types.DuplicateMember{KnowledgeID: "k1", ChunkID: "c1"},
+			Duplicates: []*types.DuplicateMember{{KnowledgeID: "k2", ChunkID: "c2", Similarity: 0.93}},
+		}},
+		RedundantChunkCount: 1,
+	}))
+
+	got, err = repo.GetReport(ctx, 2, "kb1")
+	require.NoError(t, err)
+	assert.Nil(t, got, "reports are tenant scoped")
+
+	got, err = repo.GetReport(ctx, 1, "kb1")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, types.DuplicateStatusCompleted, got.Status, "saving replaces the report")
+	assert.Equal(t, 0.8, got.Threshold)
+	require.Len(t, got.ChunkGroups, 1)
+	assert.Equal(t, "c2", got.ChunkGroups[0].Duplicates[0].ChunkID)
+	assert.Equal(t, 1, got.RedundantChunkCount)
+}
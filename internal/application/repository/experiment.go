@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// experimentRepository implements the ExperimentRepository interface
+type experimentRepository struct {
+	db *gorm.DB
+}
+
+// NewExperimentRepository creates a new shadow experiment repository
+func NewExperimentRepository(db *gorm.DB) interfaces.ExperimentRepository {
+	return &experimentRepository{db: db}
+}
+
+// CreateExperiment creates an experiment
+func (r *experimentRepository) CreateExperiment(ctx context.Context, experiment *types.Experiment) error {
+	return r.db.WithContext(ctx).Create(experiment).Error
+}
+
+// GetExperiment returns an experiment of a tenant, or nil
+func (r *experimentRepository) GetExperiment(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.Experiment, error) {
+	var experiment types.Experiment
+	err := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).First(&experiment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+// ListExperiments returns the experiments of a tenant, newest first
+func (r *experimentRepository) ListExperiments(ctx context.Context, tenantID uint64) ([]*types.Experiment, error) {
+	var experiments []*types.Experiment
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&experiments).Error
+	if err != nil {
+		return nil, err
+	}
+	return experiments, nil
+}
+
+// ListRunningExperiments returns the running experiments of a tenant
+func (r *experimentRepository) ListRunningExperiments(
+	ctx context.Context, tenantID uint64,
+) ([]*types.Experiment, error) {
+	var experiments []*types.Experiment
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND status = ?", tenantID, types.ExperimentStatusRunning).
+		Find(&experiments).Error
+	if err != nil {
+		return nil, err
+	}
+	return experiments, nil
+}
+
+// UpdateExperiment saves the settings and state of an experiment
+func (r *experimentRepository) UpdateExperiment(ctx context.Context, experiment *types.Experiment) error {
+	return r.db.WithContext(ctx).Model(experiment).
+		Select("name", "description", "sample_percent", "max_samples", "status", "updated_at").
+		Updates(experiment).Error
+}
+
+// ReserveSample takes one sample of a running experiment's budget in a
+// single conditional update, so concurrent answers never overrun it.
+func (r *experimentRepository) ReserveSample(ctx context.Context, tenantID uint64, id string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&types.Experiment{}).
+		Where("tenant_id = ? AND id = ? AND status = ? AND sampled < max_samples",
+			tenantID, id, types.ExperimentStatusRunning).
+		Updates(map[string]interface{}{
+			"sampled": gorm.Expr("sampled + 1"),
+			"status": gorm.Expr("CASE WHEN sampled + 1 >= max_samples THEN ? ELSE status END",
+				types.ExperimentStatusCompleted),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// DeleteExperiment deletes an experiment of a tenant with its samples
+func (r *experimentRepository) DeleteExperiment(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("tenant_id = ? AND id = ?", tenantID, id).Delete(&types.Experiment{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Where("experiment_id = ?", id).Delete(&types.ExperimentSample{}).Error
+	})
+}
+
+// SaveSample stores a sample, replacing an earlier one of the same
+// experiment and message left by a retried task
+func (r *experimentRepository) SaveSample(ctx context.Context, sample *types.ExperimentSample) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("experiment_id = ? AND message_id = ?", sample.ExperimentID, sample.MessageID).
+			Delete(&types.ExperimentSample{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(sample).Error
+	})
+}
+
+// ListSamples returns a page of the samples of an experiment, of one
+// verdict when winner is not nil, newest first, and their total
+func (r *experimentRepository) ListSamples(
+	ctx context.Context, experimentID string, winner *types.ExperimentWinner, page *types.Pagination,
+) ([]*types.ExperimentSample, int64, error) {
+	query := r.db.WithContext(ctx).Model(&types.ExperimentSample{}).Where("experiment_id = ?", experimentID)
+	if winner != nil {
+		query = query.Where("winner = ?", *winner)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var samples []*types.ExperimentSample
+	if err := query.
+		Order("created_at DESC, id").
+		Offset(page.Offset()).
+		Limit(page.Limit()).
+		Find(&samples).Error; err != nil {
+		return nil, 0, err
+	}
+	return samples, total, nil
+}
+
+// CountSamples counts the samples of experiments by verdict
+func (r *experimentRepository) CountSamples(
+	ctx context.Context, experimentIDs []string,
+) ([]*types.ExperimentSampleCounts, error) {
+	if len(experimentIDs) == 0 {
+		return nil, nil
+	}
+	var counts []*types.ExperimentSampleCounts
+	err := r.db.WithContext(ctx).Model(&types.ExperimentSample{}).
+		Select("experiment_id, winner, COUNT(*) AS count, "+
+			"COALESCE(SUM(shadow_latency_ms), 0) AS latency_sum, COALESCE(SUM(source_overlap), 0) AS overlap_sum").
+		Where("experiment_id IN ?", experimentIDs).
+		Group("experiment_id, winner").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupExperimentTestDB(t *testing.T) (*gorm.DB, *experimentRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&types.Experiment{}, &types.ExperimentSample{}))
+	return db, &experimentRepository{db: db}
+}
+
+func newTestExperiment(id string, tenantID uint64, maxSamples int) *types.Experiment {
+	return &types.Experiment{
+		ID: id, TenantID: tenantID, Name: id, SamplePercent: 10, MaxSamples: maxSamples,
+		Status: types.ExperimentStatusRunning, Config: types.ExperimentConfig{RerankModelID: "rerank-2"},
+	}
+}
+
+func TestExperimentRepository_ReserveSample(t *testing.T) {
+	_, repo := setupExperimentTestDB(t)
+	ctx := context.Background()
+	require.NoError(t, repo.CreateExperiment(ctx, newTestExperiment("e1", 1, 2)))
+
+	ok, err := repo.ReserveSample(ctx, 2, "e1")
+	require.NoError(t, err)
+	assert.False(t, ok, "experiments of other tenants are not sampled")
+
+	for i := 0; i < 2; i++ {
+		ok, err = repo.ReserveSample(ctx, 1, "e1")
+		require.NoError(t, err)
+		assert.True(t, ok)
+	}
+	ok, err = repo.ReserveSample(ctx, 1, "e1")
+	require.NoError(t, err)
+	assert.False(t, ok, "the budget is used up")
+
+	got, err := repo.GetExperiment(ctx, 1, "e1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, got.Sampled)
+	assert.Equal(t, types.ExperimentStatusCompleted, got.Status)
+	assert.Equal(t, "rerank-2", got.Config.RerankModelID)
+
+	running, err := repo.ListRunningExperiments(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, running)
+
+	got.MaxSamples = 5
+	got.Status = types.ExperimentStatusPaused
+	require.NoError(t, repo.UpdateExperiment(ctx, got))
+	ok, err = repo.ReserveSample(ctx, 1, "e1")
+	require.NoError(t, err)
+	assert.False(t, ok, "paused experiments are not sampled")
+}
+
+func TestExperimentRepository_Samples(t *testing.T) {
+	_, repo := setupExperimentTestDB(t)
+	ctx := context.Background()
+	require.NoError(t, repo.CreateExperiment(ctx, newTestExperiment("e1", 1, 10)))
+	require.NoError(t, repo.CreateExperiment(ctx, newTestExperiment("e2", 1, 10)))
+
+	base := time.Now()
+	for i, s := range []struct {
+		id, experimentID, messageID string
+		winner                      types.ExperimentWinner
+		latency                     int64
+		overlap                     float64
+	}{
+		{"s1", "e1", "m1", types.ExperimentWinnerShadow, 1000, 0.5},
+		{"s2", "e1", "m2", types.ExperimentWinnerShadow, 3000, 1},
+		{"s3", "e1", "m3", types.ExperimentWinnerProduction, 2000, 0.2},
+		{"s4", "e1", "m4", "", 0, 0},
+		{"s5", "e2", "m1", types.ExperimentWinnerTie, 500, 1},
+	} {
+		require.NoError(t, repo.SaveSample(ctx, &types.ExperimentSample{
+			ID: s.id, ExperimentID: s.experimentID, TenantID: 1, SessionID: "sess", MessageID: s.messageID,
+			Winner: s.winner, ShadowLatencyMs: s.latency, SourceOverlap: s.overlap,
+			ProductionSources: types.ExperimentSources{{ChunkID: "c1"}},
+			CreatedAt:         base.Add(time.Duration(i) * time.Second),
+		}))
+	}
+	// A retried task replaces its sample
+	require.NoError(t, repo.SaveSample(ctx, &types.ExperimentSample{
+		ID: "s3-retry", ExperimentID: "e1", TenantID: 1, MessageID: "m3",
+		Winner: types.ExperimentWinnerProduction, ShadowLatencyMs: 2000, SourceOverlap: 0.2,
+		CreatedAt: base.Add(10 * time.Second),
+	}))
+
+	page := &types.Pagination{Page: 1, PageSize: 2}
+	samples, total, err := repo.ListSamples(ctx, "e1", nil, page)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+	require.Len(t, samples, 2)
+	assert.Equal(t, "s3-retry", samples[0].ID)
+	assert.Equal(t, "s4", samples[1].ID)
+
+	shadow := types.ExperimentWinnerShadow
+	samples, total, err = repo.ListSamples(ctx, "e1", &shadow, &types.Pagination{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, types.ExperimentSources{{ChunkID: "c1"}}, samples[0].ProductionSources)
+
+	counts, err := repo.CountSamples(ctx, []string{"e1"})
+	require.NoError(t, err)
+	report := types.NewExperimentReport(counts)
+	assert.Equal(t, int64(4), report.Samples)
+	assert.Equal(t, int64(2), report.ShadowWins)
+	assert.Equal(t, int64(1), report.ProductionWins)
+	assert.Equal(t, int64(1), report.Failed)
+	assert.Equal(t, int64(2000), report.AvgShadowLatencyMs)
+	assert.InDelta(t, 1.7/3, report.AvgSourceOverlap, 1e-9)
+
+	require.NoError(t, repo.DeleteExperiment(ctx, 2, "e1"))
+	require.NoError(t, repo.DeleteExperiment(ctx, 1, "e1"))
+	got, err := repo.GetExperiment(ctx, 1, "e1")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	counts, err = repo.CountSamples(ctx, []string{"e1", "e2"})
+	require.NoError(t, err)
+	require.Len(t, counts, 1)
+	assert.Equal(t, "e2", counts[0].ExperimentID)
+
+	experiments, err := repo.ListExperiments(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, experiments, 1)
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// feedbackRepository implements the FeedbackRepository interface
+type feedbackRepository struct {
+	db *gorm.DB
+}
+
+// NewFeedbackRepository creates a new message feedback repository
+func NewFeedbackRepository(db *gorm.DB) interfaces.FeedbackRepository {
+	return &feedbackRepository{db: db}
+}
+
+// feedbackCountsRow is a row of the per-day and per-group counts
+type feedbackCountsRow struct {
+	GroupKey    string
+	Total       int64
+	Up          int64
+	Down        int64
+	WrongSource int64
+}
+
+// sourceCountsRow is a row of the per-group source counts
+type sourceCountsRow struct {
+	GroupKey    string
+	Bucket      int
+	Sources     int64
+	UpSources   int64
+	DownSources int64
+	Wrong       int64
+}
+
+const sourceCountsSelect = "COUNT(*) AS sources, " +
+	"COALESCE(SUM(CASE WHEN f.rating = 'up' THEN 1 ELSE 0 END), 0) AS up_sources, " +
+	"COALESCE(SUM(CASE WHEN f.rating = 'down' THEN 1 ELSE 0 END), 0) AS down_sources, " +
+	"COALESCE(SUM(CASE WHEN s.wrong THEN 1 ELSE 0 END), 0) AS wrong"
+
+func (r sourceCountsRow) counts() types.FeedbackSourceCounts {
+	return types.FeedbackSourceCounts{
+		Sources: r.Sources, UpSources: r.UpSources, DownSources: r.DownSources, Wrong: r.Wrong,
+	}
+}
+
+// scopeFeedback filters message_feedbacks, aliased as f, by a query
+func scopeFeedback(db *gorm.DB, q *types.FeedbackQuery) *gorm.DB {
+	db = db.Where("f.tenant_id = ?", q.TenantID)
+	if q.StartDay != "" {
+		db = db.Where("f.day >= ?", q.StartDay)
+	}
+	if q.EndDay != "" {
+		db = db.Where("f.day <= ?", q.EndDay)
+	}
+	if q.Rating != "" {
+		db = db.Where("f.rating = ?", q.Rating)
+	}
+	return db
+}
+
+// sourcesQuery selects the sources of the feedback matching a query, only
+// those of the query's knowledge base when it is set
+func (r *feedbackRepository) sourcesQuery(ctx context.Context, q *types.FeedbackQuery) *gorm.DB {
+	db := r.db.WithContext(ctx).
+		Table("message_feedback_sources AS s").
+		Joins("JOIN message_feedbacks AS f ON f.id = s.feedback_id")
+	db = scopeFeedback(db, q)
+	if q.KnowledgeBaseID != "" {
+		db = db.Where("s.knowledge_base_id = ?", q.KnowledgeBaseID)
+	}
+	return db
+}
+
+// feedbackQuery selects the feedback matching a query, only that on answers
+// with a source of the query's knowledge base when it is set
+func (r *feedbackRepository) feedbackQuery(ctx context.Context, q *types.FeedbackQuery) *gorm.DB {
+	db := scopeFeedback(r.db.WithContext(ctx).Table("message_feedbacks AS f"), q)
+	if q.KnowledgeBaseID != "" {
+		db = db.Where("f.id IN (?)", r.db.Model(&types.FeedbackSource{}).
+			Select("feedback_id").Where("knowledge_base_id = ?", q.KnowledgeBaseID))
+	}
+	return db
+}
+
+// SaveFeedback creates or updates a user's feedback on a message and
+// replaces its sources. An existing feedback keeps its ID and creation time.
+func (r *feedbackRepository) SaveFeedback(ctx context.Context, feedback *types.MessageFeedback) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing types.MessageFeedback
+		err := tx.Where("tenant_id = ? AND message_id = ? AND user_id = ?",
+			feedback.TenantID, feedback.MessageID, feedback.UserID).First(&existing).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if err := tx.Create(feedback).Error; err != nil {
+				return err
+			}
+		case err != nil:
+			return err
+		default:
+			feedback.ID = existing.ID
+			feedback.CreatedAt = existing.CreatedAt
+			if err := tx.Model(feedback).
+				Select("rating", "comment", "wrong_sources", "day", "question", "answer", "updated_at").
+				Updates(feedback).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("feedback_id = ?", feedback.ID).Delete(&types.FeedbackSource{}).Error; err != nil {
+				return err
+			}
+		}
+		if len(feedback.Sources) == 0 {
+			return nil
+		}
+		for _, source := range feedback.Sources {
+			source.FeedbackID = feedback.ID
+			source.TenantID = feedback.TenantID
+		}
+		return tx.CreateInBatches(feedback.Sources, 100).Error
+	})
+}
+
+// GetFeedback returns a user's feedback on a message with its sources, or nil
+func (r *feedbackRepository) GetFeedback(
+	ctx context.Context, tenantID uint64, messageID, userID string,
+) (*types.MessageFeedback, error) {
+	var feedback types.MessageFeedback
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND message_id = ? AND user_id = ?", tenantID, messageID, userID).
+		First(&feedback).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := r.db.WithContext(ctx).
+		Where("feedback_id = ?", feedback.ID).
+		Order("position").
+		Find(&feedback.Sources).Error; err != nil {
+		return nil, err
+	}
+	return &feedback, nil
+}
+
+// DeleteFeedback deletes a user's feedback on a message with its sources
+func (r *feedbackRepository) DeleteFeedback(ctx context.Context, tenantID uint64, messageID, userID string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []string
+		if err := tx.Model(&types.MessageFeedback{}).
+			Where("tenant_id = ? AND message_id = ? AND user_id = ?", tenantID, messageID, userID).
+			Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+			return err
+		}
+		if err := tx.Where("feedback_id IN ?", ids).Delete(&types.FeedbackSource{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id IN ?", ids).Delete(&types.MessageFeedback{}).Error
+	})
+}
+
+// CountByDay counts the feedback per day, in day order
+func (r *feedbackRepository) CountByDay(
+	ctx context.Context, query *types.FeedbackQuery,
+) ([]*types.FeedbackDayCounts, error) {
+	var rows []feedbackCountsRow
+	if err := r.feedbackQuery(ctx, query).
+		Select("f.day AS group_key, COUNT(*) AS total, " +
+			"COALESCE(SUM(CASE WHEN f.rating = 'up' THEN 1 ELSE 0 END), 0) AS up, " +
+			"COALESCE(SUM(CASE WHEN f.rating = 'down' THEN 1 ELSE 0 END), 0) AS down, " +
+			"COALESCE(SUM(CASE WHEN f.wrong_sources > 0 THEN 1 ELSE 0 END), 0) AS wrong_source").
+		Group("f.day").
+		Order("f.day ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	days := make([]*types.FeedbackDayCounts, 0, len(rows))
+	for _, row := range rows {
+		days = append(days, &types.FeedbackDayCounts{
+			Day: row.GroupKey,
+			FeedbackCounts: types.FeedbackCounts{
+				Total: row.Total, Up: row.Up, Down: row.Down, WrongSource: row.WrongSource,
+			},
+		})
+	}
+	return days, nil
+}
+
+// CountSourcesByKnowledgeBase counts the sources of the feedback per
+// knowledge base, most wrong sources first
+func (r *feedbackRepository) CountSourcesByKnowledgeBase(
+	ctx context.Context, query *types.FeedbackQuery,
+) ([]*types.FeedbackKnowledgeBaseCounts, error) {
+	var rows []sourceCountsRow
+	if err := r.sourcesQuery(ctx, query).
+		Select("s.knowledge_base_id AS group_key, " + sourceCountsSelect).
+		Group("s.knowledge_base_id").
+		Order("wrong DESC, sources DESC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	kbs := make([]*types.FeedbackKnowledgeBaseCounts, 0, len(rows))
+	for _, row := range rows {
+		kbs = append(kbs, &types.FeedbackKnowledgeBaseCounts{
+			KnowledgeBaseID:      row.GroupKey,
+			FeedbackSourceCounts: row.counts(),
+		})
+	}
+	return kbs, nil
+}
+
+// CountSourcesByScoreBucket counts the sources of the feedback per score bucket
+func (r *feedbackRepository) CountSourcesByScoreBucket(
+	ctx context.Context, query *types.FeedbackQuery,
+) (map[int]types.FeedbackSourceCounts, error) {
+	var rows []sourceCountsRow
+	if err := r.sourcesQuery(ctx, query).
+		Select("s.score_bucket AS bucket, " + sourceCountsSelect).
+		Group("s.score_bucket").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	buckets := make(map[int]types.FeedbackSourceCounts, len(rows))
+	for _, row := range rows {
+		buckets[row.Bucket] = row.counts()
+	}
+	return buckets, nil
+}
+
+// TopWrongSources returns the chunks most often marked as wrong
+func (r *feedbackRepository) TopWrongSources(
+	ctx context.Context, query *types.FeedbackQuery, limit int,
+) ([]*types.FeedbackWrongSource, error) {
+	var sources []*types.FeedbackWrongSource
+	if err := r.sourcesQuery(ctx, query).
+		Where("s.wrong = ?", true).
+		Select("s.chunk_id, MAX(s.knowledge_id) AS knowledge_id, " +
+			"MAX(s.knowledge_base_id) AS knowledge_base_id, " +
+			"MAX(s.knowledge_title) AS knowledge_title, COUNT(*) AS count").
+		Group("s.chunk_id").
+		Order("count DESC, s.chunk_id ASC").
+		Limit(limit).
+		Scan(&sources).Error; err != nil {
+		return nil, err
+	}
+	return sources, nil
+}
+
+// ListFeedback returns up to limit feedback with their sources, ordered by
+// ID and starting after afterID
+func (r *feedbackRepository) ListFeedback(
+	ctx context.Context, query *types.FeedbackQuery, afterID string, limit int,
+) ([]*types.MessageFeedback, error) {
+	var feedback []*types.MessageFeedback
+	if err := r.feedbackQuery(ctx, query).
+		Select("f.*").
+		Where("f.id > ?", afterID).
+		Order("f.id ASC").
+		Limit(limit).
+		Find(&feedback).Error; err != nil {
+		return nil, err
+	}
+	if len(feedback) == 0 {
+		return feedback, nil
+	}
+
+	ids := make([]string, 0, len(feedback))
+	byID := make(map[string]*types.MessageFeedback, len(feedback))
+	for _, f := range feedback {
+		ids = append(ids, f.ID)
+		byID[f.ID] = f
+	}
+	var sources []*types.FeedbackSource
+	if err := r.db.WithContext(ctx).
+		Where("feedback_id IN ?", ids).
+		Order("feedback_id, position").
+		Find(&sources).Error; err != nil {
+		return nil, err
+	}
+	for _, source := range sources {
+		if f := byID[source.FeedbackID]; f != nil {
+			f.Sources = append(f.Sources, source)
+		}
+	}
+	return feedback, nil
+}
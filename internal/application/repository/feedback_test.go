@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupFeedbackTestDB(t *testing.T) (*gorm.DB, *feedbackRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&types.MessageFeedback{}, &types.FeedbackSource{}))
+	return db, &feedbackRepository{db: db}
+}
+
+func newTestFeedback(id, messageID, userID, day string, rating types.FeedbackRating, sources ...*types.FeedbackSource) *types.MessageFeedback {
+	wrong := 0
+	for i, s := range sources {
+		s.Position = i
+		s.ScoreBucket = types.FeedbackScoreBucket(s.Score)
+		if s.Wrong {
+			wrong++
+		}
+	}
+	return &types.MessageFeedback{
+		ID: id, TenantID: 1, SessionID: "s1", MessageID: messageID, UserID: userID,
+		Rating: rating, WrongSources: wrong, Day: day, Question: "q-" + messageID, Answer: "a-" + messageID,
+		Sources: sources,
+	}
+}
+
+func TestFeedbackRepository_SaveReplacesFeedback(t *testing.T) {
+	db, repo := setupFeedbackTestDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, repo.SaveFeedback(ctx, newTestFeedback("f1", "m1", "u1", "2026-10-01", types.FeedbackRatingUp,
+		&types.FeedbackSource{ChunkID: "c1", Score: 0.9, Cited: true},
+		&types.FeedbackSource{ChunkID: "c2", Score: 0.4},
+	)))
+
+	updated := newTestFeedback("f2", "m1", "u1", "2026-10-02", types.FeedbackRatingDown,
+		&types.FeedbackSource{ChunkID: "c1", Score: 0.9, Cited: true, Wrong: true})
+	updated.Comment = "outdated"
+	require.NoError(t, repo.SaveFeedback(ctx, updated))
+	assert.Equal(t, "f1", updated.ID, "a user keeps one feedback per message")
+
+	got, err := repo.GetFeedback(ctx, 1, "m1", "u1")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, types.FeedbackRatingDown, got.Rating)
+	assert.Equal(t, "outdated", got.Comment)
+	assert.Equal(t, "2026-10-02", got.Day)
+	require.Len(t, got.Sources, 1)
+	assert.True(t, got.Sources[0].Wrong)
+
+	var count int64
+	require.NoError(t, db.Model(&types.FeedbackSource{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	got, err = repo.GetFeedback(ctx, 2, "m1", "u1")
+	require.NoError(t, err)
+	assert.Nil(t, got, "feedback is scoped to its tenant")
+
+	require.NoError(t, repo.DeleteFeedback(ctx, 1, "m1", "u1"))
+	got, err = repo.GetFeedback(ctx, 1, "m1", "u1")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+	require.NoError(t, db.Model(&types.FeedbackSource{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestFeedbackRepository_Aggregates(t *testing.T) {
+	_, repo := setupFeedbackTestDB(t)
+	ctx := context.Background()
+
+	for _, f := range []*types.MessageFeedback{
+		newTestFeedback("f1", "m1", "u1", "2026-10-01", types.FeedbackRatingUp,
+			&types.FeedbackSource{ChunkID: "c1", KnowledgeBaseID: "kb1", Score: 0.95, Cited: true},
+			&types.FeedbackSource{ChunkID: "c2", KnowledgeBaseID: "kb2", Score: 0.35}),
+		newTestFeedback("f2", "m2", "u1", "2026-10-01", types.FeedbackRatingDown,
+			&types.FeedbackSource{ChunkID: "c2", KnowledgeBaseID: "kb2", Score: 0.31, Wrong: true}),
+		newTestFeedback("f3", "m3", "u2", "2026-10-03", "",
+			&types.FeedbackSource{ChunkID: "c2", KnowledgeBaseID: "kb2", Score: 0.38, Wrong: true},
+			&types.FeedbackSource{ChunkID: "c3", KnowledgeBaseID: "kb1", Score: 0.5, Wrong: true}),
+		newTestFeedback("f4", "m4", "u1", "2026-11-01", types.FeedbackRatingUp),
+	} {
+		require.NoError(t, repo.SaveFeedback(ctx, f))
+	}
+	query := &types.FeedbackQuery{TenantID: 1, StartDay: "2026-10-01", EndDay: "2026-10-31"}
+
+	days, err := repo.CountByDay(ctx, query)
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	assert.Equal(t, "2026-10-01", days[0].Day)
+	assert.Equal(t, types.FeedbackCounts{Total: 2, Up: 1, Down: 1, WrongSource: 1}, days[0].FeedbackCounts)
+	assert.Equal(t, types.FeedbackCounts{Total: 1, WrongSource: 1}, days[1].FeedbackCounts)
+
+	kbs, err := repo.CountSourcesByKnowledgeBase(ctx, query)
+	require.NoError(t, err)
+	require.Len(t, kbs, 2)
+	assert.Equal(t, "kb2", kbs[0].KnowledgeBaseID)
+	assert.Equal(t, types.FeedbackSourceCounts{Sources: 3, UpSources: 1, DownSources: 1, Wrong: 2}, kbs[0].FeedbackSourceCounts)
+
+	buckets, err := repo.CountSourcesByScoreBucket(ctx, query)
+	require.NoError(t, err)
+	assert.Equal(t, types.FeedbackSourceCounts{Sources: 3, UpSources: 1, DownSources: 1, Wrong: 2}, buckets[3])
+	assert.Equal(t, types.FeedbackSourceCounts{Sources: 1, UpSources: 1}, buckets[9])
+
+	wrong, err := repo.TopWrongSources(ctx, query, 1)
+	require.NoError(t, err)
+	require.Len(t, wrong, 1)
+	assert.Equal(t, "c2", wrong[0].ChunkID)
+	assert.Equal(t, int64(2), wrong[0].Count)
+
+	kbQuery := *query
+	kbQuery.KnowledgeBaseID = "kb1"
+	days, err = repo.CountByDay(ctx, &kbQuery)
+	require.NoError(t, err)
+	require.Len(t, days, 2)
+	assert.Equal(t, int64(1), days[0].Total, "only feedback with a source of the knowledge base")
+
+	page, err := repo.ListFeedback(ctx, query, "", 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, "f1", page[0].ID)
+	assert.Len(t, page[0].Sources, 2)
+	page, err = repo.ListFeedback(ctx, query, page[1].ID, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, "f3", page[0].ID)
+}
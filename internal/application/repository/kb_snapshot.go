@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// kbSnapshotRepository implements the KBSnapshotRepository interface
+type kbSnapshotRepository struct {
+	db *gorm.DB
+}
+
+// NewKBSnapshotRepository creates a new knowledge base snapshot repository
+func NewKBSnapshotRepository(db *gorm.DB) interfaces.KBSnapshotRepository {
+	return &kbSnapshotRepository{db: db}
+}
+
+// CreateSnapshot adds a snapshot
+func (r *kbSnapshotRepository) CreateSnapshot(ctx context.Context, snapshot *types.KBSnapshot) error {
+	return r.db.WithContext(ctx).Create(snapshot).Error
+}
+
+// UpdateSnapshot saves every field of a snapshot
+func (r *kbSnapshotRepository) UpdateSnapshot(ctx context.Context, snapshot *types.KBSnapshot) error {
+	return r.db.WithContext(ctx).Save(snapshot).Error
+}
+
+// GetSnapshot returns a snapshot of a tenant, or nil
+func (r *kbSnapshotRepository) GetSnapshot(ctx context.Context, tenantID uint64, id string) (*types.KBSnapshot, error) {
+	var snapshot types.KBSnapshot
+	err := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).First(&snapshot).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// ListSnapshots returns the snapshots of a tenant, newest first
+func (r *kbSnapshotRepository) ListSnapshots(
+	ctx context.Context, tenantID uint64, knowledgeBaseID string,
+) ([]*types.KBSnapshot, error) {
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if knowledgeBaseID != "" {
+		query = query.Where("knowledge_base_id = ?", knowledgeBaseID)
+	}
+	var snapshots []*types.KBSnapshot
+	if err := query.Order("created_at DESC").Find(&snapshots).Error; err != nil {
+		return nil, err
+	}
+	return snapshots, nil
+}
+
+// DeleteSnapshot deletes a snapshot row
+func (r *kbSnapshotRepository) DeleteSnapshot(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Delete(&types.KBSnapshot{}).Error
+}
+
+// GetPolicy returns the snapshot policy of a knowledge base, or nil
+func (r *kbSnapshotRepository) GetPolicy(
+	ctx context.Context, tenantID uint64, knowledgeBaseID string,
+) (*types.KBSnapshotPolicy, error) {
+	var policy types.KBSnapshotPolicy
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, knowledgeBaseID).
+		First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// SavePolicy creates or replaces the snapshot policy of a knowledge base
+func (r *kbSnapshotRepository) SavePolicy(ctx context.Context, policy *types.KBSnapshotPolicy) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "knowledge_base_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "interval_hours", "retain", "next_run_at", "updated_at"}),
+	}).Create(policy).Error
+}
+
+// ListDuePolicies returns up to limit enabled policies due at now
+func (r *kbSnapshotRepository) ListDuePolicies(
+	ctx context.Context, now time.Time, limit int,
+) ([]*types.KBSnapshotPolicy, error) {
+	var policies []*types.KBSnapshotPolicy
+	err := r.db.WithContext(ctx).
+		Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at").
+		Limit(limit).
+		Find(&policies).Error
+	if err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// ClaimPolicy moves the next run of a policy from prev to next. The update
+// only matches while next_run_at still equals prev, so of several replicas
+// ticking at once exactly one wins.
+func (r *kbSnapshotRepository) ClaimPolicy(
+	ctx context.Context, knowledgeBaseID string, prev, next time.Time,
+) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&types.KBSnapshotPolicy{}).
+		Where("knowledge_base_id = ? AND enabled = ? AND next_run_at = ?", knowledgeBaseID, true, prev).
+		Updates(map[string]interface{}{"next_run_at": next, "updated_at": time.Now()})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeletePolicy deletes the snapshot policy of a knowledge base
+func (r *kbSnapshotRepository) DeletePolicy(ctx context.Context, knowledgeBaseID string) error {
+	return r.db.WithContext(ctx).
+		Where("knowledge_base_id = ?", knowledgeBaseID).
+		Delete(&types.KBSnapshotPolicy{}).Error
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestKBSnapshotRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&types.KBSnapshot{}, &types.KBSnapshotPolicy{}))
+	repo := NewKBSnapshotRepository(db)
+	ctx := context.Background()
+
+	base := time.Now().Add(-time.Hour)
+	for i, s := range []*types.KBSnapshot{
+		{ID: "s1", TenantID: 1, KnowledgeBaseID: "kb1", Status: types.KBSnapshotStatusPending},
+		{ID: "s2", TenantID: 1, KnowledgeBaseID: "kb2", Status: types.KBSnapshotStatusPending},
+		{ID: "s3", TenantID: 2, KnowledgeBaseID: "kb1", Status: types.KBSnapshotStatusPending},
+	} {
+		s.CreatedAt = base.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, repo.CreateSnapshot(ctx, s))
+	}
+
+	got, err := repo.GetSnapshot(ctx, 2, "s1")
+	require.NoError(t, err)
+	assert.Nil(t, got, "snapshots are tenant scoped")
+
+	got, err = repo.GetSnapshot(ctx, 1, "s1")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	got.Status = types.KBSnapshotStatusCompleted
+	got.Parts = types.StringArray{"a.jsonl.gz", "b.jsonl.gz"}
+	require.NoError(t, repo.UpdateSnapshot(ctx, got))
+
+	all, err := repo.ListSnapshots(ctx, 1, "")
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+	assert.Equal(t, "s2", all[0].ID, "newest first")
+	one, err := repo.ListSnapshots(ctx, 1, "kb1")
+	require.NoError(t, err)
+	require.Len(t, one, 1)
+	assert.Equal(t, types.StringArray{"a.jsonl.gz", "b.jsonl.gz"}, one[0].Parts)
+
+	require.NoError(t, repo.DeleteSnapshot(ctx, 1, "s1"))
+	got, err = repo.GetSnapshot(ctx, 1, "s1")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+}
+
+func TestKBSnapshotRepository_Policies(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&types.KBSnapshotPolicy{}))
+	repo := NewKBSnapshotRepository(db)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	due := now.Add(-time.Minute)
+	later := now.Add(time.Hour)
+	require.NoError(t, repo.SavePolicy(ctx, &types.KBSnapshotPolicy{
+		KnowledgeBaseID: "kb1", TenantID: 1, Enabled: true, IntervalHours: 24, Retain: 3, NextRunAt: &due,
+	}))
+	require.NoError(t, repo.SavePolicy(ctx, &types.KBSnapshotPolicy{
+		KnowledgeBaseID: "kb2", TenantID: 1, Enabled: true, IntervalHours: 24, Retain: 3, NextRunAt: &later,
+	}))
+	require.NoError(t, repo.SavePolicy(ctx, &types.KBSnapshotPolicy{
+		KnowledgeBaseID: "kb1", TenantID: 1, Enabled: true, IntervalHours: 12, Retain: 5, NextRunAt: &due,
+	}))
+
+	policy, err := repo.GetPolicy(ctx, 1, "kb1")
+	require.NoError(t, err)
+	require.NotNil(t, policy)
+	assert.Equal(t, 12, policy.IntervalHours, "save replaces the policy")
+
+	duePolicies, err := repo.ListDuePolicies(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, duePolicies, 1)
+	assert.Equal(t, "kb1", duePolicies[0].KnowledgeBaseID)
+
+	next := now.Add(12 * time.Hour)
+	won, err := repo.ClaimPolicy(ctx, "kb1", *duePolicies[0].NextRunAt, next)
+	require.NoError(t, err)
+	assert.True(t, won)
+	won, err = repo.ClaimPolicy(ctx, "kb1", *duePolicies[0].NextRunAt, next)
+	require.NoError(t, err)
+	assert.False(t, won, "a policy is claimed once per run")
+
+	require.NoError(t, repo.DeletePolicy(ctx, "kb1"))
+	policy, err = repo.GetPolicy(ctx, 1, "kb1")
+	require.NoError(t, err)
+	assert.Nil(t, policy)
+}
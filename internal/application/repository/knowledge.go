@@ -325,6 +325,152 @@ func (r *knowledgeRepository) UpdateKnowledgeColumns(
 	return r.db.WithContext(ctx).Model(&types.Knowledge{}).Where("id = ?", id).Updates(values).Error
 }
 
+// RewriteKnowledgeFilePaths points knowledge rows at the files they were
+// migrated to and switches the knowledge base to provider, all in one
+// transaction so readers never see the KB half on either backend. Each row
+// is only rewritten while its file_path still equals the path that was
+// copied; rows changed in the meantime (re-uploaded, deleted) are skipped
+// and returned so the caller can drop their copies. Previews stay with the
+// old files, so preview_path is cleared.
+func (r *knowledgeRepository) RewriteKnowledgeFilePaths(
+	ctx context.Context,
+	kbID string,
+	provider string,
+	moves []types.KnowledgeFileMove,
+) ([]string, error) {
+	var stale []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		stale = stale[:0]
+		for _, m := range moves {
+			result := tx.Model(&types.Knowledge{}).
+				Where("id = ? AND knowledge_base_id = ? AND file_path = ?", m.KnowledgeID, kbID, m.OldPath).
+				Updates(map[string]interface{}{
+					"file_path":         m.NewPath,
+					"encryption_key_id": m.EncryptionKeyID,
+					"preview_path":      "",
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				stale = append(stale, m.KnowledgeID)
+			}
+		}
+		return tx.Model(&types.KnowledgeBase{}).
+			Where("id = ?", kbID).
+			Update("storage_provider_config", &types.StorageProviderConfig{Provider: provider}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stale, nil
+}
+
+// MoveKnowledgeToKnowledgeBase moves a knowledge entry, its chunks and its
+// tag relations to another knowledge base in one transaction. Tags are
+// knowledge base scoped, so tagMapping maps every source tag the entry or its
+// chunks use to the matching tag of the target; tags mapped to "" are
+// dropped. The entry is marked completed again.
+func (r *knowledgeRepository) MoveKnowledgeToKnowledgeBase(
+	ctx context.Context,
+	tenantID uint64,
+	knowledgeID string,
+	targetKBID string,
+	tagMapping map[string]string,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing []types.KnowledgeTagRelation
+		if err := tx.Where("knowledge_id = ?", knowledgeID).
+			Find(&existing).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("knowledge_id = ?", knowledgeID).
+			Delete(&types.KnowledgeTagRelation{}).Error; err != nil {
+			return err
+		}
+		seen := make(map[string]struct{}, len(existing))
+		now := time.Now()
+		relations := make([]types.KnowledgeTagRelation, 0, len(existing))
+		for _, rel := range existing {
+			newTagID := tagMapping[rel.TagID]
+			if newTagID == "" {
+				continue
+			}
+			if _, dup := seen[newTagID]; dup {
+				continue
+			}
+			seen[newTagID] = struct{}{}
+			relations = append(relations, types.KnowledgeTagRelation{
+				KnowledgeID: knowledgeID,
+				TagID:       newTagID,
+				CreatedAt:   now,
+				Source:      rel.Source,
+			})
+		}
+		if len(relations) > 0 {
+			if err := tx.Create(&relations).Error; err != nil {
+				return err
+			}
+		}
+
+		for oldTagID, newTagID := range tagMapping {
+			if oldTagID == "" {
+				continue
+			}
+			if err := tx.Model(&types.Chunk{}).
+				Where("tenant_id = ? AND knowledge_id = ? AND tag_id = ?", tenantID, knowledgeID, oldTagID).
+				Update("tag_id", newTagID).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&types.Chunk{}).
+			Where("tenant_id = ? AND knowledge_id = ?", tenantID, knowledgeID).
+			Updates(map[string]interface{}{"knowledge_base_id": targetKBID, "index_generation": 0}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&types.Knowledge{}).
+			Where("tenant_id = ? AND id = ?", tenantID, knowledgeID).
+			Updates(map[string]interface{}{
+				"knowledge_base_id": targetKBID,
+				"parse_status":      types.ParseStatusCompleted,
+				"updated_at":        now,
+			}).Error
+	})
+}
+
+// ListDuplicateKnowledgeIDs maps each knowledge of sourceKBID whose file hash
+// also belongs to a knowledge of targetKBID to that target knowledge. Entries
+// without a hash and failed entries of the target never match.
+func (r *knowledgeRepository) ListDuplicateKnowledgeIDs(
+	ctx context.Context,
+	tenantID uint64,
+	sourceKBID string,
+	targetKBID string,
+) (map[string]string, error) {
+	var rows []struct {
+		SourceID string
+		TargetID string
+	}
+	if err := r.db.WithContext(ctx).
+		Table("knowledges AS s").
+		Select("s.id AS source_id, MIN(t.id) AS target_id").
+		Joins("JOIN knowledges AS t ON t.file_hash = s.file_hash AND t.tenant_id = s.tenant_id").
+		Where("s.tenant_id = ? AND s.knowledge_base_id = ? AND s.deleted_at IS NULL", tenantID, sourceKBID).
+		Where("t.knowledge_base_id = ? AND t.deleted_at IS NULL AND t.parse_status <> ?",
+			targetKBID, types.ParseStatusFailed).
+		Where("s.file_hash IS NOT NULL AND s.file_hash <> ''").
+		Group("s.id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	duplicates := make(map[string]string, len(rows))
+	for _, row := range rows {
+		duplicates[row.SourceID] = row.TargetID
+	}
+	return duplicates, nil
+}
+
 // UpdateActiveDeletingKnowledgeColumns only touches rows that are still visible
 // to normal queries and have not moved out of the transient deleting state.
 func (r *knowledgeRepository) UpdateActiveDeletingKnowledgeColumns(
@@ -737,6 +883,20 @@ func (r *knowledgeRepository) SearchKnowledgeInScopes(
 	return knowledges, hasMore, total, nil
 }
 
+// ListACLKnowledge returns the knowledge items of the knowledge bases that carry an ACL
+func (r *knowledgeRepository) ListACLKnowledge(ctx context.Context, kbIDs []string) ([]*types.Knowledge, error) {
+	if len(kbIDs) == 0 {
+		return nil, nil
+	}
+	var knowledges []*types.Knowledge
+	err := r.db.WithContext(ctx).
+		Select("id", "tenant_id", "knowledge_base_id", "metadata").
+		Where("knowledge_base_id IN ?", kbIDs).
+		Where("metadata->>? IS NOT NULL", types.KnowledgeACLMetadataKey).
+		Find(&knowledges).Error
+	return knowledges, err
+}
+
 // ListIDsByTagIDs returns all knowledge IDs that have any of the specified tag IDs (OR semantics)
 func (r *knowledgeRepository) ListIDsByTagIDs(
 	ctx context.Context,
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRewriteKnowledgeFilePaths(t *testing.T) {
+	db := setupKnowledgeTestDB(t)
+	require.NoError(t, db.Exec(knowledgeBasesTestDDL).Error)
+	require.NoError(t, db.Exec(`ALTER TABLE knowledges ADD COLUMN encryption_key_id VARCHAR(512) NOT NULL DEFAULT ''`).Error)
+	require.NoError(t, db.Exec(`ALTER TABLE knowledges ADD COLUMN preview_path TEXT NOT NULL DEFAULT ''`).Error)
+	require.NoError(t, db.Exec(`
+		INSERT INTO knowledge_bases (id, name, tenant_id, embedding_model_id, summary_model_id)
+		VALUES ('kb1', 'kb', 1, '', '')`).Error)
+	require.NoError(t, db.Exec(`
+		INSERT INTO knowledges (id, tenant_id, knowledge_base_id, file_path, preview_path)
+		VALUES ('k1', 1, 'kb1', 'local://1/k1/a.pdf', 'local://1/k1/a.pdf.thumb.jpg'),
+		       ('k2', 1, 'kb1', 'local://1/k2/reuploaded.pdf', '')`).Error)
+
+	repo := NewKnowledgeRepository(db)
+	stale, err := repo.RewriteKnowledgeFilePaths(context.Background(), "kb1", "tos", []types.KnowledgeFileMove{
+		{KnowledgeID: "k1", OldPath: "local://1/k1/a.pdf", NewPath: "tos://bucket/1/k1/a.pdf", EncryptionKeyID: "kms-key"},
+		{KnowledgeID: "k2", OldPath: "local://1/k2/b.pdf", NewPath: "tos://bucket/1/k2/b.pdf"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"k2"}, stale)
+
+	var rows []struct {
+		ID              string
+		FilePath        string
+		EncryptionKeyID string
+		PreviewPath     string
+	}
+	require.NoError(t, db.Raw(`SELECT id, file_path, encryption_key_id, preview_path FROM knowledges ORDER BY id`).Scan(&rows).Error)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "tos://bucket/1/k1/a.pdf", rows[0].FilePath)
+	assert.Equal(t, "kms-key", rows[0].EncryptionKeyID)
+	assert.Empty(t, rows[0].PreviewPath)
+	assert.Equal(t, "local://1/k2/reuploaded.pdf", rows[1].FilePath)
+
+	var providerConfig string
+	require.NoError(t, db.Raw(`SELECT storage_provider_config FROM knowledge_bases WHERE id = 'kb1'`).Scan(&providerConfig).Error)
+	assert.JSONEq(t, `{"provider":"tos"}`, providerConfig)
+}
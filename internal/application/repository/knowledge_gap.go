@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// knowledgeGapRepository implements the KnowledgeGapRepository interface
+type knowledgeGapRepository struct {
+	db *gorm.DB
+}
+
+// NewKnowledgeGapRepository creates a new knowledge gap repository
+func NewKnowledgeGapRepository(db *gorm.DB) interfaces.KnowledgeGapRepository {
+	return &knowledgeGapRepository{db: db}
+}
+
+// RecordGaps upserts the gaps, adding their counts to the existing gaps of
+// the same query and keeping the latest wording
+func (r *knowledgeGapRepository) RecordGaps(ctx context.Context, gaps []*types.KnowledgeGap) error {
+	if len(gaps) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "tenant_id"},
+			{Name: "knowledge_base_id"},
+			{Name: "query_hash"},
+		},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "query"}, Value: gorm.Expr("excluded.query")},
+			{Column: clause.Column{Name: "count"}, Value: gorm.Expr("knowledge_gaps.count + excluded.count")},
+			{Column: clause.Column{Name: "last_seen_at"}, Value: gorm.Expr("excluded.last_seen_at")},
+		},
+	}).Create(gaps).Error
+}
+
+// ListGaps returns up to limit gaps of a knowledge base, most frequent first
+func (r *knowledgeGapRepository) ListGaps(
+	ctx context.Context, tenantID uint64, kbID string, limit int,
+) ([]*types.KnowledgeGap, int64, error) {
+	db := r.db.WithContext(ctx).Model(&types.KnowledgeGap{}).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID)
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var gaps []*types.KnowledgeGap
+	if err := db.Order("count DESC, last_seen_at DESC, id ASC").Limit(limit).Find(&gaps).Error; err != nil {
+		return nil, 0, err
+	}
+	return gaps, total, nil
+}
+
+// SaveEmbeddings stores the query embeddings of gaps
+func (r *knowledgeGapRepository) SaveEmbeddings(ctx context.Context, gaps []*types.KnowledgeGap) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, gap := range gaps {
+			if err := tx.Model(&types.KnowledgeGap{}).Where("id = ?", gap.ID).Updates(map[string]interface{}{
+				"embedding":          gap.Embedding,
+				"embedding_model_id": gap.EmbeddingModelID,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteGaps deletes gaps of a knowledge base
+func (r *knowledgeGapRepository) DeleteGaps(
+	ctx context.Context, tenantID uint64, kbID string, ids []string,
+) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND id IN ?", tenantID, kbID, ids).
+		Delete(&types.KnowledgeGap{})
+	return result.RowsAffected, result.Error
+}
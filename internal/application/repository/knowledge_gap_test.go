@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestKnowledgeGapRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&types.KnowledgeGap{}))
+	repo := &knowledgeGapRepository{db: db}
+	ctx := context.Background()
+
+	record := func(id, kbID, query string, at time.Time) {
+		t.Helper()
+		log := &types.QueryLog{
+			TenantID: 1, Query: query, QueryHash: types.QueryHash(query), ZeroResult: true,
+			KnowledgeBases: []*types.QueryLogKnowledgeBase{{KnowledgeBaseID: kbID}},
+		}
+		gaps := types.NewKnowledgeGaps(log, at)
+		require.Len(t, gaps, 1)
+		gaps[0].ID = id
+		require.NoError(t, repo.RecordGaps(ctx, gaps))
+	}
+	first := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	record("g1", "kb1", "Parking rules?", first)
+	record("g2", "kb1", "parking rules", first.Add(time.Hour))
+	record("g3", "kb1", "Lunch menu", first)
+	record("g4", "kb2", "Parking rules?", first)
+
+	gaps, total, err := repo.ListGaps(ctx, 1, "kb1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	require.Len(t, gaps, 2)
+	assert.Equal(t, "g1", gaps[0].ID, "the same query adds to the existing gap")
+	assert.Equal(t, int64(2), gaps[0].Count)
+	assert.Equal(t, "parking rules", gaps[0].Query)
+	assert.True(t, gaps[0].FirstSeenAt.Equal(first))
+	assert.True(t, gaps[0].LastSeenAt.Equal(first.Add(time.Hour)))
+
+	gaps[1].Embedding = types.KnowledgeGapVector{0.5, 1}
+	gaps[1].EmbeddingModelID = "m1"
+	require.NoError(t, repo.SaveEmbeddings(ctx, gaps[1:]))
+	gaps, _, err = repo.ListGaps(ctx, 1, "kb1", 1)
+	require.NoError(t, err)
+	require.Len(t, gaps, 1)
+	assert.Empty(t, gaps[0].Embedding)
+	gaps, _, err = repo.ListGaps(ctx, 1, "kb1", 10)
+	require.NoError(t, err)
+	assert.Equal(t, types.KnowledgeGapVector{0.5, 1}, gaps[1].Embedding)
+	assert.Equal(t, "m1", gaps[1].EmbeddingModelID)
+
+	deleted, err := repo.DeleteGaps(ctx, 1, "kb1", []string{"g1", "g4"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted, "gaps of other knowledge bases are not deleted")
+	_, total, err = repo.ListGaps(ctx, 1, "kb2", 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+}
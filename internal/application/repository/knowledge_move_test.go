@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+const knowledgeMoveTestDDL = `
+CREATE TABLE IF NOT EXISTS knowledge_tag_relations (
+    knowledge_id VARCHAR(36) NOT NULL,
+    tag_id VARCHAR(36) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    source VARCHAR(16) NOT NULL DEFAULT 'manual',
+    PRIMARY KEY (knowledge_id, tag_id)
+);
+CREATE TABLE IF NOT EXISTS chunks (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    tag_id VARCHAR(36),
+    index_generation INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+`
+
+func setupKnowledgeMoveTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db := setupKnowledgeTestDB(t)
+	require.NoError(t, db.Exec(knowledgeMoveTestDDL).Error)
+	return db
+}
+
+func TestMoveKnowledgeToKnowledgeBase_RewritesTags(t *testing.T) {
+	db := setupKnowledgeMoveTestDB(t)
+	repo := &knowledgeRepository{db: db}
+	ctx := context.Background()
+	knowledgeID, other := uuid.New().String(), uuid.New().String()
+	require.NoError(t, db.Exec(`
+		INSERT INTO knowledges (id, tenant_id, knowledge_base_id, parse_status)
+		VALUES (?, 1, 'src', 'processing'), (?, 1, 'src', 'completed')
+	`, knowledgeID, other).Error)
+	require.NoError(t, db.Exec(`
+		INSERT INTO knowledge_tag_relations (knowledge_id, tag_id) VALUES (?, 'a'), (?, 'b')
+	`, knowledgeID, knowledgeID).Error)
+	require.NoError(t, db.Exec(`
+		INSERT INTO chunks (id, tenant_id, knowledge_id, knowledge_base_id, tag_id, index_generation) VALUES
+		('c1', 1, ?, 'src', 'a', 3), ('c2', 1, ?, 'src', 'b', 3), ('c3', 1, ?, 'src', 'a', 3)
+	`, knowledgeID, knowledgeID, other).Error)
+
+	err := repo.MoveKnowledgeToKnowledgeBase(ctx, 1, knowledgeID, "dst", map[string]string{"a": "x", "b": ""})
+	require.NoError(t, err)
+
+	var tags []string
+	require.NoError(t, db.Raw(`SELECT tag_id FROM knowledge_tag_relations WHERE knowledge_id = ?`, knowledgeID).
+		Scan(&tags).Error)
+	assert.Equal(t, []string{"x"}, tags)
+
+	type chunkRow struct {
+		ID              string
+		KnowledgeBaseID string
+		TagID           string
+		IndexGeneration int
+	}
+	var chunks []chunkRow
+	require.NoError(t, db.Raw(`SELECT id, knowledge_base_id, tag_id, index_generation FROM chunks ORDER BY id`).
+		Scan(&chunks).Error)
+	assert.Equal(t, []chunkRow{
+		{ID: "c1", KnowledgeBaseID: "dst", TagID: "x"},
+		{ID: "c2", KnowledgeBaseID: "dst", TagID: ""},
+		{ID: "c3", KnowledgeBaseID: "src", TagID: "a", IndexGeneration: 3},
+	}, chunks)
+
+	var kbID, status string
+	require.NoError(t, db.Raw(`SELECT knowledge_base_id, parse_status FROM knowledges WHERE id = ?`, knowledgeID).
+		Row().Scan(&kbID, &status))
+	assert.Equal(t, "dst", kbID)
+	assert.Equal(t, types.ParseStatusCompleted, status)
+}
+
+func TestListDuplicateKnowledgeIDs(t *testing.T) {
+	db := setupKnowledgeTestDB(t)
+	repo := &knowledgeRepository{db: db}
+	require.NoError(t, db.Exec(`
+		INSERT INTO knowledges (id, tenant_id, knowledge_base_id, parse_status, file_hash, deleted_at) VALUES
+		('s1', 1, 'src', 'completed', 'h1', NULL),
+		('s2', 1, 'src', 'completed', 'h2', NULL),
+		('s3', 1, 'src', 'completed', '', NULL),
+		('s4', 1, 'src', 'completed', 'h4', NULL),
+		('s5', 1, 'src', 'completed', 'h5', NULL),
+		('t1', 1, 'dst', 'completed', 'h1', NULL),
+		('t2', 1, 'dst', 'failed', 'h2', NULL),
+		('t3', 1, 'dst', 'completed', '', NULL),
+		('t4', 1, 'dst', 'completed', 'h4', '2026-06-16 12:00:00'),
+		('t5', 2, 'dst', 'completed', 'h5', NULL)
+	`).Error)
+
+	duplicates, err := repo.ListDuplicateKnowledgeIDs(context.Background(), 1, "src", "dst")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"s1": "t1"}, duplicates)
+}
+
+func TestListACLKnowledge(t *testing.T) {
+	db := setupKnowledgeTestDB(t)
+	repo := &knowledgeRepository{db: db}
+	require.NoError(t, db.Exec(`
+		INSERT INTO knowledges (id, tenant_id, knowledge_base_id, parse_status, metadata) VALUES
+		('k1', 1, 'kb1', 'completed', '{"acl":{"users":["u1"],"groups":[]}}'),
+		('k2', 1, 'kb1', 'completed', '{"author":"a"}'),
+		('k3', 1, 'kb1', 'completed', NULL),
+		('k4', 1, 'kb2', 'completed', '{"acl":{"users":[],"groups":["admin"]}}')
+	`).Error)
+
+	knowledges, err := repo.ListACLKnowledge(context.Background(), []string{"kb1"})
+	require.NoError(t, err)
+	require.Len(t, knowledges, 1)
+	assert.Equal(t, "k1", knowledges[0].ID)
+	assert.Equal(t, []string{"u1"}, knowledges[0].ACL().Users)
+}
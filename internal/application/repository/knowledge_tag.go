@@ -6,6 +6,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/types"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // SetKnowledgeTags replaces all tags for a single knowledge entry.
@@ -40,6 +41,7 @@ func (r *knowledgeRepository) SetKnowledgeTags(
 				KnowledgeID: knowledgeID,
 				TagID:       tagID,
 				CreatedAt:   now,
+				Source:      types.KnowledgeTagSourceManual,
 			})
 		}
 		if len(relations) == 0 {
@@ -49,6 +51,44 @@ func (r *knowledgeRepository) SetKnowledgeTags(
 	})
 }
 
+// SetAutoKnowledgeTags replaces the auto-attached tags of a single knowledge
+// entry. Manually attached tags are kept, including when auto-tagging picks
+// the same tag again.
+func (r *knowledgeRepository) SetAutoKnowledgeTags(
+	ctx context.Context,
+	knowledgeID string,
+	tagIDs []string,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("knowledge_id = ? AND source = ?", knowledgeID, types.KnowledgeTagSourceAuto).
+			Delete(&types.KnowledgeTagRelation{}).Error; err != nil {
+			return err
+		}
+		seen := make(map[string]struct{}, len(tagIDs))
+		now := time.Now()
+		relations := make([]types.KnowledgeTagRelation, 0, len(tagIDs))
+		for _, tagID := range tagIDs {
+			if tagID == "" {
+				continue
+			}
+			if _, dup := seen[tagID]; dup {
+				continue
+			}
+			seen[tagID] = struct{}{}
+			relations = append(relations, types.KnowledgeTagRelation{
+				KnowledgeID: knowledgeID,
+				TagID:       tagID,
+				CreatedAt:   now,
+				Source:      types.KnowledgeTagSourceAuto,
+			})
+		}
+		if len(relations) == 0 {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&relations).Error
+	})
+}
+
 // GetKnowledgeTags returns tags for multiple knowledge IDs.
 // The result is a map from knowledge ID to its tag list.
 func (r *knowledgeRepository) GetKnowledgeTags(
@@ -62,13 +102,14 @@ func (r *knowledgeRepository) GetKnowledgeTags(
 
 	// Query relations and join with knowledge_tags to get full tag info
 	type relationWithTag struct {
-		KnowledgeID string `gorm:"column:knowledge_id"`
+		KnowledgeID    string `gorm:"column:knowledge_id"`
+		RelationSource string `gorm:"column:relation_source"`
 		types.KnowledgeTag
 	}
 	var rows []relationWithTag
 	if err := r.db.WithContext(ctx).
 		Table("knowledge_tag_relations AS ktr").
-		Select("ktr.knowledge_id, kt.id, kt.seq_id, kt.tenant_id, kt.knowledge_base_id, kt.name, kt.color, kt.sort_order, kt.created_at, kt.updated_at").
+		Select("ktr.knowledge_id, ktr.source AS relation_source, kt.id, kt.seq_id, kt.tenant_id, kt.knowledge_base_id, kt.name, kt.color, kt.sort_order, kt.created_at, kt.updated_at").
 		Joins("JOIN knowledge_tags AS kt ON ktr.tag_id = kt.id").
 		Where("ktr.knowledge_id IN (?)", knowledgeIDs).
 		Find(&rows).Error; err != nil {
@@ -77,6 +118,7 @@ func (r *knowledgeRepository) GetKnowledgeTags(
 
 	for _, row := range rows {
 		tag := row.KnowledgeTag
+		tag.Source = row.RelationSource
 		result[row.KnowledgeID] = append(result[row.KnowledgeID], &tag)
 	}
 	return result, nil
@@ -26,6 +26,7 @@ CREATE TABLE IF NOT EXISTS knowledge_tags (
     seq_id INTEGER NOT NULL,
     tenant_id INTEGER NOT NULL,
     knowledge_base_id VARCHAR(36) NOT NULL,
+    parent_id VARCHAR(36) NOT NULL DEFAULT '',
     name VARCHAR(128) NOT NULL,
     color VARCHAR(32),
     sort_order INTEGER NOT NULL DEFAULT 0,
@@ -36,6 +37,7 @@ CREATE TABLE IF NOT EXISTS knowledge_tag_relations (
     knowledge_id VARCHAR(36) NOT NULL,
     tag_id VARCHAR(36) NOT NULL,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    source VARCHAR(16) NOT NULL DEFAULT 'manual',
     PRIMARY KEY (knowledge_id, tag_id)
 );
 CREATE TABLE IF NOT EXISTS chunks (
@@ -118,6 +120,45 @@ func TestGetKnowledgeTags_ReturnsTagDetails(t *testing.T) {
 	assert.True(t, names["beta"])
 }
 
+func TestSetAutoKnowledgeTags_KeepsManualTags(t *testing.T) {
+	db := setupKnowledgeTagTestDB(t)
+	repo := &knowledgeRepository{db: db}
+	kbID, knowledgeID, tagA, tagB := seedKnowledgeTagFixture(t, db)
+	ctx := context.Background()
+	tagC := uuid.New().String()
+	require.NoError(t, db.Exec(`
+		INSERT INTO knowledge_tags (id, seq_id, tenant_id, knowledge_base_id, name)
+		VALUES (?, 3, 1, ?, 'gamma')
+	`, tagC, kbID).Error)
+
+	require.NoError(t, repo.SetKnowledgeTags(ctx, knowledgeID, []string{tagA}))
+	require.NoError(t, repo.SetAutoKnowledgeTags(ctx, knowledgeID, []string{tagA, tagB}))
+
+	sources := func() map[string]string {
+		tagMap, err := repo.GetKnowledgeTags(ctx, []string{knowledgeID})
+		require.NoError(t, err)
+		out := map[string]string{}
+		for _, tag := range tagMap[knowledgeID] {
+			out[tag.Name] = tag.Source
+		}
+		return out
+	}
+	assert.Equal(t, map[string]string{
+		"alpha": types.KnowledgeTagSourceManual,
+		"beta":  types.KnowledgeTagSourceAuto,
+	}, sources())
+
+	// Re-tagging replaces the auto tags only.
+	require.NoError(t, repo.SetAutoKnowledgeTags(ctx, knowledgeID, []string{tagC}))
+	assert.Equal(t, map[string]string{
+		"alpha": types.KnowledgeTagSourceManual,
+		"gamma": types.KnowledgeTagSourceAuto,
+	}, sources())
+
+	require.NoError(t, repo.SetAutoKnowledgeTags(ctx, knowledgeID, nil))
+	assert.Equal(t, map[string]string{"alpha": types.KnowledgeTagSourceManual}, sources())
+}
+
 func TestDeleteKnowledgeTagRelations(t *testing.T) {
 	db := setupKnowledgeTagTestDB(t)
 	repo := &knowledgeRepository{db: db}
@@ -201,3 +242,59 @@ func TestBatchCountReferences_ScopedToKnowledgeBase(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, int64(1), counts[tag1].KnowledgeCount)
 }
+
+func TestDeleteUnusedTags_KeepsParentTags(t *testing.T) {
+	db := setupKnowledgeTagTestDB(t)
+	knowledgeRepo := &knowledgeRepository{db: db}
+	tagRepo := &knowledgeTagRepository{db: db}
+	ctx := context.Background()
+
+	kbID := uuid.New().String()
+	doc := uuid.New().String()
+	parent := uuid.New().String()
+	child := uuid.New().String()
+	unused := uuid.New().String()
+
+	require.NoError(t, db.Exec(`
+		INSERT INTO knowledges (id, tenant_id, knowledge_base_id, type, title, parse_status)
+		VALUES (?, 1, ?, 'file', 'doc', 'completed')
+	`, doc, kbID).Error)
+	require.NoError(t, db.Exec(`
+		INSERT INTO knowledge_tags (id, seq_id, tenant_id, knowledge_base_id, parent_id, name)
+		VALUES (?, 1, 1, ?, '', 'dept'), (?, 2, 1, ?, ?, 'team'), (?, 3, 1, ?, '', 'unused')
+	`, parent, kbID, child, kbID, parent, unused, kbID).Error)
+	require.NoError(t, knowledgeRepo.SetKnowledgeTags(ctx, doc, []string{child}))
+
+	deleted, err := tagRepo.DeleteUnusedTags(ctx, 1, kbID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	tags, err := tagRepo.ListAllByKB(ctx, 1, kbID)
+	require.NoError(t, err)
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	assert.ElementsMatch(t, []string{"dept", "team"}, names)
+}
+
+func TestReparentChildren_MovesChildrenToGrandparent(t *testing.T) {
+	db := setupKnowledgeTagTestDB(t)
+	tagRepo := &knowledgeTagRepository{db: db}
+	ctx := context.Background()
+
+	kbID := uuid.New().String()
+	root := uuid.New().String()
+	mid := uuid.New().String()
+	leaf := uuid.New().String()
+	require.NoError(t, db.Exec(`
+		INSERT INTO knowledge_tags (id, seq_id, tenant_id, knowledge_base_id, parent_id, name)
+		VALUES (?, 1, 1, ?, '', 'root'), (?, 2, 1, ?, ?, 'mid'), (?, 3, 1, ?, ?, 'leaf')
+	`, root, kbID, mid, kbID, root, leaf, kbID, mid).Error)
+
+	require.NoError(t, tagRepo.ReparentChildren(ctx, 1, kbID, mid, root))
+
+	got, err := tagRepo.GetByID(ctx, 1, leaf)
+	require.NoError(t, err)
+	assert.Equal(t, root, got.ParentID)
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// knowledgeVersionRepository implements the KnowledgeVersionRepository interface
+type knowledgeVersionRepository struct {
+	db *gorm.DB
+}
+
+// NewKnowledgeVersionRepository creates a new knowledge version repository
+func NewKnowledgeVersionRepository(db *gorm.DB) interfaces.KnowledgeVersionRepository {
+	return &knowledgeVersionRepository{db: db}
+}
+
+// CreateVersion adds a version
+func (r *knowledgeVersionRepository) CreateVersion(ctx context.Context, version *types.KnowledgeVersion) error {
+	return r.db.WithContext(ctx).Create(version).Error
+}
+
+// ListVersions returns the versions of a knowledge, newest first
+func (r *knowledgeVersionRepository) ListVersions(
+	ctx context.Context, tenantID uint64, knowledgeID string,
+) ([]*types.KnowledgeVersion, error) {
+	var versions []*types.KnowledgeVersion
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_id = ?", tenantID, knowledgeID).
+		Order("version DESC").
+		Find(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// GetLatestVersion returns the newest version of a knowledge, or nil
+func (r *knowledgeVersionRepository) GetLatestVersion(
+	ctx context.Context, tenantID uint64, knowledgeID string,
+) (*types.KnowledgeVersion, error) {
+	var version types.KnowledgeVersion
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_id = ?", tenantID, knowledgeID).
+		Order("version DESC").
+		First(&version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// UpdateChunkStats records the chunk diff of an indexed version
+func (r *knowledgeVersionRepository) UpdateChunkStats(
+	ctx context.Context, tenantID uint64, id string, added, reused, removed int,
+) error {
+	return r.db.WithContext(ctx).Model(&types.KnowledgeVersion{}).
+		Where("tenant_id = ? AND id = ?", tenantID, id).
+		Updates(map[string]interface{}{
+			"chunks_added":   added,
+			"chunks_reused":  reused,
+			"chunks_removed": removed,
+		}).Error
+}
+
+// DeleteByKnowledgeID deletes the versions of a knowledge
+func (r *knowledgeVersionRepository) DeleteByKnowledgeID(ctx context.Context, tenantID uint64, knowledgeID string) error {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_id = ?", tenantID, knowledgeID).
+		Delete(&types.KnowledgeVersion{}).Error
+}
@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestKnowledgeVersionRepository(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&types.KnowledgeVersion{}))
+	repo := NewKnowledgeVersionRepository(db)
+	ctx := context.Background()
+
+	latest, err := repo.GetLatestVersion(ctx, 1, "k1")
+	require.NoError(t, err)
+	assert.Nil(t, latest)
+
+	for _, v := range []*types.KnowledgeVersion{
+		{ID: "v1", TenantID: 1, KnowledgeID: "k1", Version: 1, FileName: "a.md"},
+		{ID: "v2", TenantID: 1, KnowledgeID: "k1", Version: 2, FileName: "a.md"},
+		{ID: "v9", TenantID: 2, KnowledgeID: "k1", Version: 9},
+	} {
+		require.NoError(t, repo.CreateVersion(ctx, v))
+	}
+
+	latest, err = repo.GetLatestVersion(ctx, 1, "k1")
+	require.NoError(t, err)
+	require.NotNil(t, latest)
+	assert.Equal(t, "v2", latest.ID)
+
+	require.NoError(t, repo.UpdateChunkStats(ctx, 1, "v2", 3, 7, 2))
+	versions, err := repo.ListVersions(ctx, 1, "k1")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, 2, versions[0].Version, "newest first")
+	assert.Equal(t, [3]int{3, 7, 2}, [3]int{versions[0].ChunksAdded, versions[0].ChunksReused, versions[0].ChunksRemoved})
+
+	require.NoError(t, repo.DeleteByKnowledgeID(ctx, 1, "k1"))
+	versions, err = repo.ListVersions(ctx, 1, "k1")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+	versions, err = repo.ListVersions(ctx, 2, "k1")
+	require.NoError(t, err)
+	assert.Len(t, versions, 1, "other tenants are untouched")
+}
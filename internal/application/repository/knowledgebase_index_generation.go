@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrIndexMigrationInProgress is returned when a knowledge base already
+	// has a pending index generation.
+	ErrIndexMigrationInProgress = errors.New("index migration already in progress")
+	// ErrIndexGenerationMismatch is returned when the generation being
+	// switched to or aborted is no longer the pending one.
+	ErrIndexGenerationMismatch = errors.New("index generation is not pending")
+)
+
+// activeIndexGeneration restricts a chunk query to chunks visible to
+// retrieval, hiding rows staged by an in-flight index migration. Rows
+// written outside a migration carry generation 0 and short-circuit the
+// subquery.
+func activeIndexGeneration(db *gorm.DB) *gorm.DB {
+	return db.Where("(chunks.index_generation = 0 OR chunks.index_generation <= " +
+		"(SELECT kb.index_generation FROM knowledge_bases kb WHERE kb.id = chunks.knowledge_base_id))")
+}
+
+// BeginIndexGeneration reserves a new generation for a migration. It is
+// numbered above every generation any chunk of the KB still carries, so
+// rows left behind by an aborted attempt can never be mistaken for the new
+// one. The check-and-set is a conditional UPDATE, so two concurrent callers
+// cannot both reserve a generation.
+func (r *knowledgeBaseRepository) BeginIndexGeneration(ctx context.Context, id string) (int64, error) {
+	var generation int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var kb types.KnowledgeBase
+		if err := tx.Select("index_generation", "pending_index_generation").
+			Where("id = ?", id).First(&kb).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrKnowledgeBaseNotFound
+			}
+			return err
+		}
+		if kb.PendingIndexGeneration != 0 {
+			return ErrIndexMigrationInProgress
+		}
+		var maxChunkGeneration *int64
+		if err := tx.Unscoped().Model(&types.Chunk{}).
+			Where("knowledge_base_id = ?", id).
+			Select("MAX(index_generation)").Scan(&maxChunkGeneration).Error; err != nil {
+			return err
+		}
+		generation = kb.IndexGeneration + 1
+		if maxChunkGeneration != nil && *maxChunkGeneration >= generation {
+			generation = *maxChunkGeneration + 1
+		}
+		result := tx.Model(&types.KnowledgeBase{}).
+			Where("id = ? AND pending_index_generation = 0", id).
+			UpdateColumn("pending_index_generation", generation)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrIndexMigrationInProgress
+		}
+		return nil
+	})
+	return generation, err
+}
+
+// AbortIndexGeneration releases a pending generation without switching to
+// it. The staged chunks are left for the caller to delete.
+func (r *knowledgeBaseRepository) AbortIndexGeneration(ctx context.Context, id string, generation int64) error {
+	result := r.db.WithContext(ctx).Model(&types.KnowledgeBase{}).
+		Where("id = ? AND pending_index_generation = ?", id, generation).
+		UpdateColumn("pending_index_generation", 0)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrIndexGenerationMismatch
+	}
+	return nil
+}
+
+// SwitchIndexGeneration makes the pending generation active in a single
+// transaction. Chunks of older generations whose knowledge was rebuilt in
+// the new generation, limited to chunkTypes, are retired (soft-deleted)
+// in the same transaction; chunks of knowledge the migration did not touch
+// stay visible. Leftovers of aborted attempts, numbered between the old
+// and the new active generation, are retired with them. When embeddingModelID is set, the knowledge base and the
+// rebuilt knowledge are switched to it as well. Returns the IDs of the
+// retired chunks so the caller can drop their index rows.
+func (r *knowledgeBaseRepository) SwitchIndexGeneration(
+	ctx context.Context, id string, generation int64, chunkTypes []types.ChunkType, embeddingModelID string,
+) ([]string, error) {
+	var retired []string
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var kb types.KnowledgeBase
+		if err := tx.Select("index_generation").Where("id = ?", id).First(&kb).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrKnowledgeBaseNotFound
+			}
+			return err
+		}
+		updates := map[string]interface{}{
+			"index_generation":         generation,
+			"pending_index_generation": 0,
+		}
+		if embeddingModelID != "" {
+			updates["embedding_model_id"] = embeddingModelID
+		}
+		// The conditional UPDATE takes the row lock first, serializing
+		// against a concurrent abort of the same generation.
+		result := tx.Model(&types.KnowledgeBase{}).
+			Where("id = ? AND pending_index_generation = ?", id, generation).
+			UpdateColumns(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrIndexGenerationMismatch
+		}
+
+		rebuilt := tx.Model(&types.Chunk{}).
+			Select("DISTINCT knowledge_id").
+			Where("knowledge_base_id = ? AND index_generation = ?", id, generation)
+		if err := tx.Model(&types.Chunk{}).
+			Where("knowledge_base_id = ? AND index_generation < ?", id, generation).
+			Where("(chunk_type IN ? AND knowledge_id IN (?)) OR index_generation > ?",
+				chunkTypes, rebuilt, kb.IndexGeneration).
+			Pluck("id", &retired).Error; err != nil {
+			return err
+		}
+		for start := 0; start < len(retired); start += 5000 {
+			end := min(start+5000, len(retired))
+			if err := tx.Where("id IN ?", retired[start:end]).Delete(&types.Chunk{}).Error; err != nil {
+				return err
+			}
+		}
+
+		if embeddingModelID != "" {
+			if err := tx.Model(&types.Knowledge{}).
+				Where("knowledge_base_id = ? AND id IN (?)", id, rebuilt).
+				UpdateColumn("embedding_model_id", embeddingModelID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return retired, err
+}
@@ -38,7 +38,10 @@ CREATE TABLE IF NOT EXISTS knowledge_bases (
     asr_config TEXT,
     vector_store_id VARCHAR(36),
     wiki_config TEXT,
+    auto_tag_config TEXT,
     indexing_strategy TEXT,
+    index_generation INTEGER NOT NULL DEFAULT 0,
+    pending_index_generation INTEGER NOT NULL DEFAULT 0,
     creator_id VARCHAR(36),
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -0,0 +1,137 @@
+package neo4j
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+const (
+	// healthCheckTTL is how long a successful connectivity check is trusted.
+	healthCheckTTL = 30 * time.Second
+	// healthCheckTimeout bounds a connectivity check so a hung Neo4j does
+	// not stall the chat turn asking whether memory is available.
+	healthCheckTimeout = 2 * time.Second
+	// breakerThreshold consecutive failed operations open the breaker.
+	breakerThreshold = 3
+	// breakerCooldown is how long an open breaker reports memory as
+	// unavailable before connectivity is checked again.
+	breakerCooldown = 30 * time.Second
+
+	// saveEpisodeAttempts and saveEpisodeBackoff bound the retries of a
+	// transiently failing episode write; the backoff doubles per attempt.
+	saveEpisodeAttempts = 3
+	saveEpisodeBackoff  = 200 * time.Millisecond
+)
+
+// healthGuard caches Neo4j connectivity and trips a circuit breaker after
+// repeated transient failures, so an unreachable Neo4j turns memory off
+// for a while instead of failing every chat turn. Once the cooldown has
+// passed, the next availability check probes the server again: success
+// closes the breaker, failure reopens it.
+type healthGuard struct {
+	verify func(ctx context.Context) error
+	now    func() time.Time
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	healthy   bool
+	failures  int
+	openUntil time.Time
+}
+
+func newHealthGuard(verify func(ctx context.Context) error) *healthGuard {
+	return &healthGuard{verify: verify, now: time.Now}
+}
+
+// available reports whether Neo4j may be used, verifying connectivity when
+// the cached result has expired.
+func (g *healthGuard) available(ctx context.Context) bool {
+	g.mu.Lock()
+	now := g.now()
+	if now.Before(g.openUntil) {
+		g.mu.Unlock()
+		return false
+	}
+	if g.healthy && now.Sub(g.checkedAt) < healthCheckTTL {
+		g.mu.Unlock()
+		return true
+	}
+	g.mu.Unlock()
+
+	checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	err := g.verify(checkCtx)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.checkedAt = g.now()
+	if err != nil {
+		if g.healthy || g.openUntil.IsZero() {
+			logger.Warnf(ctx, "Neo4j memory store is unreachable, memory disabled for %s: %v", breakerCooldown, err)
+		}
+		g.healthy = false
+		g.failures = 0
+		g.openUntil = g.checkedAt.Add(breakerCooldown)
+		return false
+	}
+	if !g.healthy && !g.openUntil.IsZero() {
+		logger.Infof(ctx, "Neo4j memory store is reachable again")
+	}
+	g.healthy = true
+	g.failures = 0
+	g.openUntil = time.Time{}
+	return true
+}
+
+// record feeds an operation's outcome to the breaker. Only transient
+// errors count; a bad query says nothing about the server's health.
+func (g *healthGuard) record(ctx context.Context, err error) {
+	if err != nil && !isTransient(err) {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if err == nil {
+		g.failures = 0
+		return
+	}
+	g.failures++
+	if g.failures < breakerThreshold {
+		return
+	}
+	logger.Warnf(ctx, "Neo4j memory store failed %d times in a row, memory disabled for %s: %v",
+		g.failures, breakerCooldown, err)
+	g.healthy = false
+	g.failures = 0
+	g.openUntil = g.now().Add(breakerCooldown)
+}
+
+// isTransient reports whether err is worth retrying: a lost connection, an
+// unavailable cluster member or a timeout.
+func isTransient(err error) bool {
+	return neo4j.IsRetryable(err) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// retryTransient runs op up to attempts times, backing off exponentially
+// between attempts while the error is transient.
+func retryTransient(ctx context.Context, attempts int, backoff time.Duration, op func() error) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = op(); err == nil || !isTransient(err) || attempt == attempts {
+			return err
+		}
+		logger.Warnf(ctx, "Neo4j write failed (attempt %d/%d), retrying in %s: %v", attempt, attempts, backoff, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	return err
+}
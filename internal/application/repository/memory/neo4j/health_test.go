@@ -0,0 +1,106 @@
+package neo4j
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.now }
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+func newTestGuard(verify func(context.Context) error) (*healthGuard, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+	g := newHealthGuard(verify)
+	g.now = clock.Now
+	return g, clock
+}
+
+func TestHealthGuardCachesConnectivity(t *testing.T) {
+	ctx := context.Background()
+	checks := 0
+	g, clock := newTestGuard(func(context.Context) error { checks++; return nil })
+
+	assert.True(t, g.available(ctx))
+	assert.True(t, g.available(ctx))
+	assert.Equal(t, 1, checks)
+
+	clock.Advance(healthCheckTTL)
+	assert.True(t, g.available(ctx))
+	assert.Equal(t, 2, checks)
+}
+
+func TestHealthGuardFailedCheckOpensBreaker(t *testing.T) {
+	ctx := context.Background()
+	down := true
+	checks := 0
+	g, clock := newTestGuard(func(context.Context) error {
+		checks++
+		if down {
+			return context.DeadlineExceeded
+		}
+		return nil
+	})
+
+	assert.False(t, g.available(ctx))
+	// No probing while the breaker is open.
+	assert.False(t, g.available(ctx))
+	assert.Equal(t, 1, checks)
+
+	down = false
+	clock.Advance(breakerCooldown)
+	assert.True(t, g.available(ctx))
+	assert.Equal(t, 2, checks)
+}
+
+func TestHealthGuardTripsOnTransientFailures(t *testing.T) {
+	ctx := context.Background()
+	g, clock := newTestGuard(func(context.Context) error { return nil })
+	assert.True(t, g.available(ctx))
+
+	// Query errors do not count, and a success resets the streak.
+	for i := 0; i < breakerThreshold; i++ {
+		g.record(ctx, errors.New("syntax error"))
+	}
+	g.record(ctx, context.DeadlineExceeded)
+	g.record(ctx, nil)
+	for i := 0; i < breakerThreshold-1; i++ {
+		g.record(ctx, context.DeadlineExceeded)
+	}
+	assert.True(t, g.available(ctx))
+
+	g.record(ctx, context.DeadlineExceeded)
+	assert.False(t, g.available(ctx))
+
+	clock.Advance(breakerCooldown)
+	assert.True(t, g.available(ctx))
+}
+
+func TestRetryTransient(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	err := retryTransient(ctx, 3, time.Millisecond, func() error {
+		calls++
+		if calls < 3 {
+			return context.DeadlineExceeded
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	permanent := errors.New("constraint violation")
+	err = retryTransient(ctx, 3, time.Millisecond, func() error { calls++; return permanent })
+	assert.ErrorIs(t, err, permanent)
+	assert.Equal(t, 1, calls)
+
+	calls = 0
+	err = retryTransient(ctx, 2, time.Millisecond, func() error { calls++; return context.DeadlineExceeded })
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 2, calls)
+}
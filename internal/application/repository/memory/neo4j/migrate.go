@@ -0,0 +1,103 @@
+package neo4j
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+)
+
+// tenantScopeMigration moves a memory graph written before tenant isolation
+// onto per-tenant nodes. Entities used to be merged by name across tenants:
+// each one is copied once per tenant whose episodes mention it, along with
+// its aliases, embeddings and relationships, and the shared node is removed.
+// Users, profile facts and session summaries are assigned the tenant of the
+// episodes they belong to. Every step only touches nodes without a tenant_id,
+// so running it again is a no-op.
+var tenantScopeMigration = []string{
+	`
+		MATCH (n:Entity)
+		WHERE n.tenant_id IS NULL
+		MATCH (e:Episode)-[:MENTIONS]->(n)
+		WITH n, collect(DISTINCT e.tenant_id) AS tenants
+		UNWIND tenants AS tenant
+		MERGE (c:Entity {tenant_id: tenant, name: n.name})
+		ON CREATE SET c += properties(n)
+		WITH n, c, tenant
+		MATCH (e:Episode {tenant_id: tenant})-[:MENTIONS]->(n)
+		MERGE (e)-[:MENTIONS]->(c)
+	`,
+	// A relationship is copied to every tenant that has both its ends, or
+	// only to the tenant of the episode that asserted it when that is known.
+	`
+		MATCH (s:Entity)-[r:RELATED_TO]->(t:Entity)
+		WHERE s.tenant_id IS NULL AND t.tenant_id IS NULL
+		OPTIONAL MATCH (origin:Episode {id: r.episode_id})
+		MATCH (cs:Entity {name: s.name}), (ct:Entity {name: t.name})
+		WHERE cs.tenant_id IS NOT NULL AND ct.tenant_id = cs.tenant_id
+			AND (origin IS NULL OR origin.tenant_id = cs.tenant_id)
+		CREATE (cs)-[nr:RELATED_TO]->(ct)
+		SET nr = properties(r)
+	`,
+	`
+		MATCH (n:Entity)
+		WHERE n.tenant_id IS NULL
+		DETACH DELETE n
+	`,
+	`
+		MATCH (u:User)
+		WHERE u.tenant_id IS NULL
+		OPTIONAL MATCH (e:Episode {user_id: u.id})
+		WITH u, e
+		ORDER BY e.created_at DESC
+		WITH u, head(collect(e.tenant_id)) AS tenant
+		SET u.tenant_id = coalesce(tenant, 0)
+	`,
+	`
+		MATCH (u:User)-[:HAS_FACT]->(f:ProfileFact)
+		WHERE f.tenant_id IS NULL
+		SET f.tenant_id = u.tenant_id
+	`,
+	`
+		MATCH (s:SessionSummary)
+		WHERE s.tenant_id IS NULL
+		OPTIONAL MATCH (e:Episode {session_id: s.session_id})
+		WITH s, head(collect(e.tenant_id)) AS tenant
+		SET s.tenant_id = coalesce(tenant, 0)
+	`,
+}
+
+// MigrateTenantScope brings a memory graph written before tenant isolation
+// up to date; see tenantScopeMigration. It also creates the index entities
+// are looked up by.
+func MigrateTenantScope(ctx context.Context, driver neo4j.Driver) error {
+	if driver == nil {
+		return nil
+	}
+	session := driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	// Schema changes cannot share a transaction with data writes.
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return tx.Run(ctx, `
+			CREATE INDEX memory_entity_tenant_name IF NOT EXISTS
+			FOR (n:Entity) ON (n.tenant_id, n.name)
+		`, nil)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create memory entity index: %v", err)
+	}
+
+	_, err = session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		for _, query := range tenantScopeMigration {
+			if _, err := tx.Run(ctx, query, nil); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to migrate memory graph to per-tenant nodes: %v", err)
+	}
+	return nil
+}
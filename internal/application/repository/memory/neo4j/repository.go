@@ -3,6 +3,9 @@ package neo4j
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -11,138 +14,1118 @@ import (
 	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
 )
 
+// semanticMinScore is the lowest vector index score, cosine similarity
+// mapped onto [0, 1], at which an episode counts as related.
+const semanticMinScore = 0.75
+
 type MemoryRepository struct {
 	driver neo4j.Driver
+	// vectorIndexes records the embedding dimensions whose vector indexes
+	// are known to exist.
+	vectorIndexes sync.Map
+	health        *healthGuard
 }
 
 func NewMemoryRepository(driver neo4j.Driver) interfaces.MemoryRepository {
-	return &MemoryRepository{driver: driver}
+	r := &MemoryRepository{driver: driver}
+	if driver != nil {
+		r.health = newHealthGuard(driver.VerifyConnectivity)
+	}
+	return r
 }
 
+// IsAvailable reports whether Neo4j is configured and reachable. The check
+// is cached and short-circuited while the circuit breaker is open.
 func (r *MemoryRepository) IsAvailable(ctx context.Context) bool {
-	return r.driver != nil
+	return r.driver != nil && r.health.available(ctx)
 }
 
 func (r *MemoryRepository) SaveEpisode(ctx context.Context, episode *types.Episode, entities []*types.Entity, relations []*types.Relationship) error {
+	// Schema changes cannot share a transaction with data writes, so the
+	// vector indexes are created up front.
+	embeddings := episode.Embeddings
+	if embeddings != nil {
+		dims := map[int]bool{len(embeddings.Summary): true}
+		for _, vector := range embeddings.Entities {
+			dims[len(vector)] = true
+		}
+		for dim := range dims {
+			if dim == 0 {
+				continue
+			}
+			if err := r.ensureVectorIndexes(ctx, dim); err != nil {
+				logger.Warnf(ctx, "failed to create memory vector index, saving episode without embeddings: %v", err)
+				embeddings = nil
+				break
+			}
+		}
+	}
+
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
 	defer session.Close(ctx)
 
-	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		// 1. Create Episode Node
-		createEpisodeQuery := `
-			MERGE (e:Episode {id: $id})
-			SET e.user_id = $user_id,
-				e.session_id = $session_id,
-				e.summary = $summary,
-				e.created_at = $created_at
+	// The write is idempotent, every node and edge is merged, so a
+	// transient failure is retried as a whole.
+	err := retryTransient(ctx, saveEpisodeAttempts, saveEpisodeBackoff, func() error {
+		_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+			return nil, saveEpisode(ctx, tx, episode, entities, relations, embeddings)
+		})
+		return err
+	})
+	r.health.record(ctx, err)
+	if err != nil {
+		logger.Errorf(ctx, "failed to save episode: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// saveEpisode writes the episode, its entities and relationships in tx.
+func saveEpisode(ctx context.Context, tx neo4j.ManagedTransaction, episode *types.Episode,
+	entities []*types.Entity, relations []*types.Relationship, embeddings *types.MemoryEmbeddings,
+) error {
+	// 1. Create Episode Node
+	createEpisodeQuery := `
+		MERGE (e:Episode {id: $id})
+		SET e.tenant_id = $tenant_id,
+			e.user_id = $user_id,
+			e.session_id = $session_id,
+			e.summary = $summary,
+			e.created_at = $created_at,
+			e.salience = $salience,
+			e.access_count = coalesce(e.access_count, 0)
+	`
+	_, err := tx.Run(ctx, createEpisodeQuery, map[string]interface{}{
+		"id":         episode.ID,
+		"tenant_id":  int64(episode.TenantID),
+		"user_id":    episode.UserID,
+		"session_id": episode.SessionID,
+		"summary":    episode.Summary,
+		"created_at": episode.CreatedAt.Format(time.RFC3339),
+		"salience":   episode.Salience,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create episode: %w", err)
+	}
+	if embeddings != nil && len(embeddings.Summary) > 0 {
+		if err := setEmbedding(ctx, tx, "Episode", map[string]interface{}{"id": episode.ID},
+			embeddings.ModelID, embeddings.Summary); err != nil {
+			return fmt.Errorf("failed to store episode embedding: %w", err)
+		}
+	}
+
+	// Entities merged away by consolidation live on as aliases; file new
+	// mentions under the surviving entity instead of recreating them.
+	canonical, err := resolveAliases(ctx, tx, episode.TenantID, entities, relations)
+	if err != nil {
+		return err
+	}
+
+	// 2. Create Entity Nodes and MENTIONS relationships. Entities are
+	// merged by name within the tenant only.
+	for _, entity := range entities {
+		createEntityQuery := `
+			MERGE (n:Entity {tenant_id: $tenant_id, name: $name})
+			SET n.type = $type,
+				n.description = $description
+			WITH n
+			MATCH (e:Episode {id: $episode_id})
+			MERGE (e)-[:MENTIONS]->(n)
 		`
-		_, err := tx.Run(ctx, createEpisodeQuery, map[string]interface{}{
-			"id":         episode.ID,
-			"user_id":    episode.UserID,
-			"session_id": episode.SessionID,
-			"summary":    episode.Summary,
-			"created_at": episode.CreatedAt.Format(time.RFC3339),
+		_, err := tx.Run(ctx, createEntityQuery, map[string]interface{}{
+			"tenant_id":   int64(episode.TenantID),
+			"name":        canonical(entity.Title),
+			"type":        entity.Type,
+			"description": entity.Description,
+			"episode_id":  episode.ID,
 		})
 		if err != nil {
-			return nil, fmt.Errorf("failed to create episode: %v", err)
-		}
-
-		// 2. Create Entity Nodes and MENTIONS relationships
-		for _, entity := range entities {
-			createEntityQuery := `
-				MERGE (n:Entity {name: $name})
-				SET n.type = $type,
-					n.description = $description
-				WITH n
-				MATCH (e:Episode {id: $episode_id})
-				MERGE (e)-[:MENTIONS]->(n)
-			`
-			_, err := tx.Run(ctx, createEntityQuery, map[string]interface{}{
-				"name":        entity.Title,
-				"type":        entity.Type,
-				"description": entity.Description,
-				"episode_id":  episode.ID,
-			})
+			return fmt.Errorf("failed to create entity %s: %w", entity.Title, err)
+		}
+		if embeddings != nil && len(embeddings.Entities[entity.Title]) > 0 {
+			key := map[string]interface{}{"tenant_id": int64(episode.TenantID), "name": canonical(entity.Title)}
+			if err := setEmbedding(ctx, tx, "Entity", key,
+				embeddings.ModelID, embeddings.Entities[entity.Title]); err != nil {
+				return fmt.Errorf("failed to store entity embedding %s: %w", entity.Title, err)
+			}
+		}
+	}
+
+	// 3. Create Relationships between Entities. A relationship that
+	// still holds is reaffirmed; otherwise a new one is recorded, valid
+	// from this episode on, leaving invalidated ones as history.
+	for _, rel := range relations {
+		createRelQuery := `
+			MATCH (s:Entity {tenant_id: $tenant_id, name: $source})
+			MATCH (t:Entity {tenant_id: $tenant_id, name: $target})
+			OPTIONAL MATCH (s)-[cur:RELATED_TO {description: $description}]->(t)
+			WHERE cur.valid_to IS NULL
+			FOREACH (_ IN CASE WHEN cur IS NULL THEN [1] ELSE [] END |
+				CREATE (s)-[:RELATED_TO {
+					description: $description,
+					weight: $weight,
+					valid_from: $valid_from,
+					user_id: $user_id,
+					episode_id: $episode_id
+				}]->(t))
+			FOREACH (_ IN CASE WHEN cur IS NULL THEN [] ELSE [1] END |
+				SET cur.weight = $weight)
+		`
+		_, err := tx.Run(ctx, createRelQuery, map[string]interface{}{
+			"tenant_id":   int64(episode.TenantID),
+			"source":      canonical(rel.Source),
+			"target":      canonical(rel.Target),
+			"description": rel.Description,
+			"weight":      rel.Weight,
+			"valid_from":  episode.CreatedAt.Format(time.RFC3339),
+			"user_id":     episode.UserID,
+			"episode_id":  episode.ID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create relationship between %s and %s: %w", rel.Source, rel.Target, err)
+		}
+	}
+
+	return nil
+}
+
+// resolveAliases maps the names used by an episode to the entities they
+// were merged into within the tenant. Names that are not an alias map to
+// themselves.
+func resolveAliases(ctx context.Context, tx neo4j.ManagedTransaction, tenantID uint64,
+	entities []*types.Entity, relations []*types.Relationship,
+) (func(string) string, error) {
+	names := make([]string, 0, len(entities)+2*len(relations))
+	for _, entity := range entities {
+		names = append(names, entity.Title)
+	}
+	for _, rel := range relations {
+		names = append(names, rel.Source, rel.Target)
+	}
+	aliases := make(map[string]string)
+	if len(names) > 0 {
+		res, err := tx.Run(ctx, `
+			UNWIND $names AS name
+			MATCH (n:Entity {tenant_id: $tenant_id})
+			WHERE name IN n.aliases
+			RETURN DISTINCT name, n.name AS canonical
+		`, map[string]interface{}{"tenant_id": int64(tenantID), "names": names})
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve entity aliases: %w", err)
+		}
+		for res.Next(ctx) {
+			record := res.Record()
+			name, _ := record.Get("name")
+			target, _ := record.Get("canonical")
+			aliases[name.(string)], _ = target.(string)
+		}
+		if err := res.Err(); err != nil {
+			return nil, fmt.Errorf("failed to resolve entity aliases: %w", err)
+		}
+	}
+	return func(name string) string {
+		if target := aliases[name]; target != "" {
+			return target
+		}
+		return name
+	}, nil
+}
+
+func (r *MemoryRepository) FindRelatedEpisodes(ctx context.Context, tenantID uint64, userID string, keywords []string, asOf time.Time, limit int) ([]*types.Episode, error) {
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		// Episodes mentioning a keyword entity rank before those reached
+		// through a relationship that held at as_of.
+		query := `
+			MATCH (k:Entity {tenant_id: $tenant_id})
+			WHERE k.name IN $keywords OR any(alias IN coalesce(k.aliases, []) WHERE alias IN $keywords)
+			OPTIONAL MATCH (k)-[r:RELATED_TO]-(m:Entity)
+			WHERE (r.valid_from IS NULL OR datetime(r.valid_from) <= datetime($as_of))
+				AND (r.valid_to IS NULL OR datetime(r.valid_to) > datetime($as_of))
+			WITH collect(DISTINCT k) AS direct, collect(DISTINCT m) AS related
+			UNWIND direct + [n IN related WHERE NOT n IN direct] AS n
+			MATCH (e:Episode)-[:MENTIONS]->(n)
+			WHERE e.tenant_id = $tenant_id AND e.user_id = $user_id AND e.archived_at IS NULL
+				AND datetime(e.created_at) <= datetime($as_of)
+			WITH e, max(CASE WHEN n IN direct THEN 1 ELSE 0 END) AS direct_match
+			RETURN e
+			ORDER BY direct_match DESC, e.created_at DESC
+			LIMIT $limit
+		`
+
+		res, err := tx.Run(ctx, query, map[string]interface{}{
+			"tenant_id": int64(tenantID),
+			"user_id":   userID,
+			"keywords":  keywords,
+			"as_of":     asOf.Format(time.RFC3339),
+			"limit":     limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var episodes []*types.Episode
+		for res.Next(ctx) {
+			record := res.Record()
+			node, _ := record.Get("e")
+			episodes = append(episodes, episodeFromProps(node.(neo4j.Node).Props))
+		}
+		return episodes, res.Err()
+	})
+
+	r.health.record(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.([]*types.Episode), nil
+}
+
+func (r *MemoryRepository) FindSimilarEpisodes(ctx context.Context, tenantID uint64, userID string, modelID string, vector []float32, limit int) ([]*types.Episode, error) {
+	if len(vector) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	dim := len(vector)
+	if err := r.ensureVectorIndexes(ctx, dim); err != nil {
+		return nil, err
+	}
+	_, modelProp := embeddingProperties(dim)
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		// The indexes span every tenant and user, so more candidates than
+		// needed are fetched before narrowing down to the user's episodes.
+		query := fmt.Sprintf(`
+			CALL {
+				CALL db.index.vector.queryNodes($episode_index, $candidates, $vector) YIELD node, score
+				WITH node AS e, score
+				WHERE e.tenant_id = $tenant_id AND e.user_id = $user_id
+					AND e.archived_at IS NULL AND e.%[1]s = $model_id
+				RETURN e, score
+				UNION ALL
+				CALL db.index.vector.queryNodes($entity_index, $candidates, $vector) YIELD node, score
+				WITH node AS n, score
+				WHERE n.tenant_id = $tenant_id AND n.%[1]s = $model_id
+				MATCH (e:Episode {tenant_id: $tenant_id, user_id: $user_id})-[:MENTIONS]->(n)
+				WHERE e.archived_at IS NULL
+				RETURN e, score
+			}
+			WITH e, max(score) AS score
+			WHERE score >= $min_score
+			RETURN e
+			ORDER BY score DESC
+			LIMIT $limit
+		`, modelProp)
+		res, err := tx.Run(ctx, query, map[string]interface{}{
+			"episode_index": vectorIndexName("Episode", dim),
+			"entity_index":  vectorIndexName("Entity", dim),
+			"candidates":    max(limit*20, 100),
+			"vector":        toFloat64s(vector),
+			"tenant_id":     int64(tenantID),
+			"user_id":       userID,
+			"model_id":      modelID,
+			"min_score":     semanticMinScore,
+			"limit":         limit,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		var episodes []*types.Episode
+		for res.Next(ctx) {
+			node, _ := res.Record().Get("e")
+			episodes = append(episodes, episodeFromProps(node.(neo4j.Node).Props))
+		}
+		return episodes, res.Err()
+	})
+	r.health.record(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.([]*types.Episode), nil
+}
+
+func episodeFromProps(props map[string]interface{}) *types.Episode {
+	episode := &types.Episode{}
+	episode.ID, _ = props["id"].(string)
+	episode.UserID, _ = props["user_id"].(string)
+	episode.SessionID, _ = props["session_id"].(string)
+	episode.Summary, _ = props["summary"].(string)
+	if tenantID, ok := props["tenant_id"].(int64); ok {
+		episode.TenantID = uint64(tenantID)
+	}
+	if createdAt, ok := props["created_at"].(string); ok {
+		episode.CreatedAt, _ = time.Parse(time.RFC3339, createdAt)
+	}
+	episode.Salience, _ = props["salience"].(float64)
+	if accessCount, ok := props["access_count"].(int64); ok {
+		episode.AccessCount = int(accessCount)
+	}
+	if lastAccessedAt, ok := props["last_accessed_at"].(string); ok {
+		episode.LastAccessedAt, _ = time.Parse(time.RFC3339, lastAccessedAt)
+	}
+	episode.Archived = props["archived_at"] != nil
+	return episode
+}
+
+func (r *MemoryRepository) SaveProfileFacts(ctx context.Context, tenantID uint64, userID string, episodeID string, facts []*types.UserProfileFact) error {
+	var upserts, removals []map[string]interface{}
+	for _, fact := range facts {
+		row := map[string]interface{}{"category": fact.Category, "key": fact.Key, "value": fact.Value}
+		if fact.Value == "" {
+			removals = append(removals, row)
+		} else {
+			upserts = append(upserts, row)
+		}
+	}
+	if len(upserts) == 0 && len(removals) == 0 {
+		return nil
+	}
+
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		if len(removals) > 0 {
+			_, err := tx.Run(ctx, `
+				UNWIND $facts AS fact
+				MATCH (f:ProfileFact {tenant_id: $tenant_id, user_id: $user_id, category: fact.category, key: fact.key})
+				DETACH DELETE f
+			`, map[string]interface{}{"tenant_id": int64(tenantID), "user_id": userID, "facts": removals})
 			if err != nil {
-				return nil, fmt.Errorf("failed to create entity %s: %v", entity.Title, err)
-			}
-		}
-
-		// 3. Create Relationships between Entities
-		for _, rel := range relations {
-			createRelQuery := `
-				MATCH (s:Entity {name: $source})
-				MATCH (t:Entity {name: $target})
-				MERGE (s)-[r:RELATED_TO {description: $description}]->(t)
-				SET r.weight = $weight
-			`
-			_, err := tx.Run(ctx, createRelQuery, map[string]interface{}{
-				"source":      rel.Source,
-				"target":      rel.Target,
-				"description": rel.Description,
-				"weight":      rel.Weight,
+				return nil, fmt.Errorf("failed to remove profile facts: %v", err)
+			}
+		}
+		if len(upserts) > 0 {
+			// Facts keep a DERIVED_FROM link to every episode that stated
+			// them, as provenance.
+			_, err := tx.Run(ctx, `
+				MERGE (u:User {tenant_id: $tenant_id, id: $user_id})
+				WITH u
+				UNWIND $facts AS fact
+				MERGE (f:ProfileFact {tenant_id: $tenant_id, user_id: $user_id, category: fact.category, key: fact.key})
+				SET f.value = fact.value,
+					f.updated_at = $now
+				MERGE (u)-[:HAS_FACT]->(f)
+				WITH f
+				OPTIONAL MATCH (e:Episode {id: $episode_id, tenant_id: $tenant_id})
+				FOREACH (_ IN CASE WHEN e IS NULL THEN [] ELSE [1] END | MERGE (f)-[:DERIVED_FROM]->(e))
+			`, map[string]interface{}{
+				"tenant_id":  int64(tenantID),
+				"user_id":    userID,
+				"episode_id": episodeID,
+				"facts":      upserts,
+				"now":        time.Now().Format(time.RFC3339),
 			})
 			if err != nil {
-				return nil, fmt.Errorf("failed to create relationship between %s and %s: %v", rel.Source, rel.Target, err)
+				return nil, fmt.Errorf("failed to save profile facts: %v", err)
 			}
 		}
-
 		return nil, nil
 	})
-
 	if err != nil {
-		logger.Errorf(ctx, "failed to save episode: %v", err)
+		logger.Errorf(ctx, "failed to save profile facts: %v", err)
 		return err
 	}
 
 	return nil
 }
 
-func (r *MemoryRepository) FindRelatedEpisodes(ctx context.Context, userID string, keywords []string, limit int) ([]*types.Episode, error) {
+func (r *MemoryRepository) GetProfileFacts(ctx context.Context, tenantID uint64, userID string) ([]*types.UserProfileFact, error) {
 	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
 	defer session.Close(ctx)
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
-		querySimple := `
-			MATCH (e:Episode)-[:MENTIONS]->(n:Entity)
-			WHERE e.user_id = $user_id AND n.name IN $keywords
-			RETURN DISTINCT e
+		res, err := tx.Run(ctx, `
+			MATCH (:User {tenant_id: $tenant_id, id: $user_id})-[:HAS_FACT]->(f:ProfileFact)
+			OPTIONAL MATCH (f)-[:DERIVED_FROM]->(e:Episode)
+			RETURN f.category AS category, f.key AS key, f.value AS value,
+				f.updated_at AS updated_at, collect(e.id) AS episodes
+			ORDER BY category, key
+		`, map[string]interface{}{"tenant_id": int64(tenantID), "user_id": userID})
+		if err != nil {
+			return nil, err
+		}
+		var facts []*types.UserProfileFact
+		for res.Next(ctx) {
+			record := res.Record()
+			fact := &types.UserProfileFact{}
+			if v, ok := record.Get("category"); ok {
+				fact.Category, _ = v.(string)
+			}
+			if v, ok := record.Get("key"); ok {
+				fact.Key, _ = v.(string)
+			}
+			if v, ok := record.Get("value"); ok {
+				fact.Value, _ = v.(string)
+			}
+			if v, ok := record.Get("updated_at"); ok {
+				if updatedAt, ok := v.(string); ok {
+					fact.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+				}
+			}
+			if v, ok := record.Get("episodes"); ok {
+				episodes, _ := v.([]interface{})
+				for _, id := range episodes {
+					if s, ok := id.(string); ok {
+						fact.SourceEpisodeIDs = append(fact.SourceEpisodeIDs, s)
+					}
+				}
+			}
+			facts = append(facts, fact)
+		}
+		return facts, res.Err()
+	})
+	r.health.record(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.([]*types.UserProfileFact), nil
+}
+
+func (r *MemoryRepository) GetSessionSummary(ctx context.Context, tenantID uint64, sessionID string) (*types.SessionSummary, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		res, err := tx.Run(ctx, `
+			MATCH (s:SessionSummary {tenant_id: $tenant_id, session_id: $session_id})
+			RETURN s
+		`, map[string]interface{}{"tenant_id": int64(tenantID), "session_id": sessionID})
+		if err != nil {
+			return nil, err
+		}
+		if !res.Next(ctx) {
+			return (*types.SessionSummary)(nil), res.Err()
+		}
+		node, _ := res.Record().Get("s")
+		props := node.(neo4j.Node).Props
+		summary := &types.SessionSummary{TenantID: tenantID, SessionID: sessionID}
+		summary.UserID, _ = props["user_id"].(string)
+		summary.Summary, _ = props["summary"].(string)
+		if count, ok := props["message_count"].(int64); ok {
+			summary.MessageCount = int(count)
+		}
+		// summarized_until keeps sub-second precision: it is compared
+		// against message creation times.
+		if until, ok := props["summarized_until"].(string); ok {
+			summary.SummarizedUntil, _ = time.Parse(time.RFC3339Nano, until)
+		}
+		if updatedAt, ok := props["updated_at"].(string); ok {
+			summary.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+		}
+		return summary, nil
+	})
+	r.health.record(ctx, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.(*types.SessionSummary), nil
+}
+
+func (r *MemoryRepository) SaveSessionSummary(ctx context.Context, summary *types.SessionSummary) error {
+	return r.write(ctx, `
+		MERGE (s:SessionSummary {tenant_id: $tenant_id, session_id: $session_id})
+		SET s.user_id = $user_id,
+			s.summary = $summary,
+			s.summarized_until = $summarized_until,
+			s.message_count = $message_count,
+			s.updated_at = $updated_at
+	`, map[string]interface{}{
+		"tenant_id":        int64(summary.TenantID),
+		"session_id":       summary.SessionID,
+		"user_id":          summary.UserID,
+		"summary":          summary.Summary,
+		"summarized_until": summary.SummarizedUntil.Format(time.RFC3339Nano),
+		"message_count":    int64(summary.MessageCount),
+		"updated_at":       summary.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+func (r *MemoryRepository) RecordEpisodeAccess(ctx context.Context, tenantID uint64, episodeIDs []string) error {
+	if len(episodeIDs) == 0 {
+		return nil
+	}
+	return r.write(ctx, `
+		MATCH (e:Episode {tenant_id: $tenant_id})
+		WHERE e.id IN $ids
+		SET e.access_count = coalesce(e.access_count, 0) + 1,
+			e.last_accessed_at = $now
+	`, map[string]interface{}{
+		"tenant_id": int64(tenantID),
+		"ids":       episodeIDs,
+		"now":       time.Now().Format(time.RFC3339),
+	})
+}
+
+func (r *MemoryRepository) ListMemoryUsers(ctx context.Context) ([]types.MemoryUser, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		res, err := tx.Run(ctx, `
+			MATCH (e:Episode)
+			WHERE e.archived_at IS NULL AND e.user_id IS NOT NULL
+			RETURN DISTINCT e.tenant_id AS tenant_id, e.user_id AS user_id
+		`, nil)
+		if err != nil {
+			return nil, err
+		}
+		var users []types.MemoryUser
+		for res.Next(ctx) {
+			record := res.Record()
+			tenantID, _ := record.Get("tenant_id")
+			userID, _ := record.Get("user_id")
+			user := types.MemoryUser{}
+			user.UserID, _ = userID.(string)
+			if id, ok := tenantID.(int64); ok {
+				user.TenantID = uint64(id)
+			}
+			if user.UserID != "" {
+				users = append(users, user)
+			}
+		}
+		return users, res.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.([]types.MemoryUser), nil
+}
+
+func (r *MemoryRepository) ListUserEpisodes(ctx context.Context, tenantID uint64, userID string) ([]*types.Episode, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		// Only the scoring fields are returned; embeddings can be large.
+		res, err := tx.Run(ctx, `
+			MATCH (e:Episode {tenant_id: $tenant_id, user_id: $user_id})
+			WHERE e.archived_at IS NULL
+			RETURN e {.id, .tenant_id, .user_id, .session_id, .created_at,
+				.salience, .access_count, .last_accessed_at} AS e
+		`, map[string]interface{}{"tenant_id": int64(tenantID), "user_id": userID})
+		if err != nil {
+			return nil, err
+		}
+		var episodes []*types.Episode
+		for res.Next(ctx) {
+			props, _ := res.Record().Get("e")
+			if m, ok := props.(map[string]interface{}); ok {
+				episodes = append(episodes, episodeFromProps(m))
+			}
+		}
+		return episodes, res.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.([]*types.Episode), nil
+}
+
+func (r *MemoryRepository) ListEpisodes(ctx context.Context, tenantID uint64, userID string, offset int, limit int) ([]*types.Episode, int64, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	type page struct {
+		episodes []*types.Episode
+		total    int64
+	}
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		var p page
+		res, err := tx.Run(ctx, `MATCH (e:Episode {tenant_id: $tenant_id, user_id: $user_id}) RETURN count(e) AS total`,
+			map[string]interface{}{"tenant_id": int64(tenantID), "user_id": userID})
+		if err != nil {
+			return nil, err
+		}
+		if res.Next(ctx) {
+			v, _ := res.Record().Get("total")
+			p.total, _ = v.(int64)
+		}
+		if err := res.Err(); err != nil {
+			return nil, err
+		}
+
+		res, err = tx.Run(ctx, `
+			MATCH (e:Episode {tenant_id: $tenant_id, user_id: $user_id})
+			RETURN e {.id, .tenant_id, .user_id, .session_id, .summary, .created_at,
+				.salience, .access_count, .last_accessed_at, .archived_at} AS e
 			ORDER BY e.created_at DESC
+			SKIP $offset
 			LIMIT $limit
-		`
+		`, map[string]interface{}{"tenant_id": int64(tenantID), "user_id": userID, "offset": offset, "limit": limit})
+		if err != nil {
+			return nil, err
+		}
+		for res.Next(ctx) {
+			props, _ := res.Record().Get("e")
+			if m, ok := props.(map[string]interface{}); ok {
+				p.episodes = append(p.episodes, episodeFromProps(m))
+			}
+		}
+		return p, res.Err()
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	p := result.(page)
+	return p.episodes, p.total, nil
+}
+
+func (r *MemoryRepository) DeleteEpisode(ctx context.Context, tenantID uint64, userID string, episodeID string) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
 
-		res, err := tx.Run(ctx, querySimple, map[string]interface{}{
-			"user_id":  userID,
-			"keywords": keywords,
-			"limit":    limit,
+	found, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		res, err := tx.Run(ctx, `MATCH (e:Episode {id: $id, tenant_id: $tenant_id, user_id: $user_id}) RETURN count(e) AS n`,
+			map[string]interface{}{"id": episodeID, "tenant_id": int64(tenantID), "user_id": userID})
+		if err != nil {
+			return nil, err
+		}
+		var n int64
+		if res.Next(ctx) {
+			v, _ := res.Record().Get("n")
+			n, _ = v.(int64)
+		}
+		return n > 0, res.Err()
+	})
+	if err != nil {
+		return err
+	}
+	if !found.(bool) {
+		return types.ErrMemoryNotFound
+	}
+
+	return r.DeleteEpisodes(ctx, tenantID, []string{episodeID})
+}
+
+func (r *MemoryRepository) ArchiveEpisodes(ctx context.Context, tenantID uint64, episodeIDs []string) error {
+	if len(episodeIDs) == 0 {
+		return nil
+	}
+	return r.write(ctx, `
+		MATCH (e:Episode {tenant_id: $tenant_id})
+		WHERE e.id IN $ids
+		SET e.archived_at = $now
+	`, map[string]interface{}{
+		"tenant_id": int64(tenantID),
+		"ids":       episodeIDs,
+		"now":       time.Now().Format(time.RFC3339),
+	})
+}
+
+func (r *MemoryRepository) DeleteEpisodes(ctx context.Context, tenantID uint64, episodeIDs []string) error {
+	if len(episodeIDs) == 0 {
+		return nil
+	}
+	return r.write(ctx, `
+		MATCH (e:Episode {tenant_id: $tenant_id})
+		WHERE e.id IN $ids
+		OPTIONAL MATCH (e)-[:MENTIONS]->(n:Entity)
+		WITH collect(DISTINCT e) AS episodes, collect(DISTINCT n) AS entities
+		FOREACH (e IN episodes | DETACH DELETE e)
+		WITH entities
+		UNWIND entities AS n
+		WITH n
+		WHERE NOT ()-[:MENTIONS]->(n)
+		DETACH DELETE n
+	`, map[string]interface{}{"tenant_id": int64(tenantID), "ids": episodeIDs})
+}
+
+// write runs a single write query in its own transaction.
+func (r *MemoryRepository) write(ctx context.Context, query string, params map[string]interface{}) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		return tx.Run(ctx, query, params)
+	})
+	return err
+}
+
+// embeddingProperties names the node properties holding a vector of the
+// given dimension and the model that produced it. Each dimension has its own
+// property, and vector index, since tenants use different embedding models.
+func embeddingProperties(dim int) (vectorProp, modelProp string) {
+	return fmt.Sprintf("embedding_%d", dim), fmt.Sprintf("embedding_model_%d", dim)
+}
+
+func vectorIndexName(label string, dim int) string {
+	return fmt.Sprintf("memory_%s_embedding_%d", strings.ToLower(label), dim)
+}
+
+// ensureVectorIndexes creates the Episode and Entity vector indexes for
+// embeddings of dimension dim.
+func (r *MemoryRepository) ensureVectorIndexes(ctx context.Context, dim int) error {
+	if _, ok := r.vectorIndexes.Load(dim); ok {
+		return nil
+	}
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	vectorProp, _ := embeddingProperties(dim)
+	for _, label := range []string{"Episode", "Entity"} {
+		query := fmt.Sprintf(`
+			CREATE VECTOR INDEX %s IF NOT EXISTS
+			FOR (n:%s) ON (n.%s)
+			OPTIONS {indexConfig: {`+"`vector.dimensions`"+`: %d, `+"`vector.similarity_function`"+`: 'cosine'}}
+		`, vectorIndexName(label, dim), label, vectorProp, dim)
+		_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+			return tx.Run(ctx, query, nil)
 		})
+		if err != nil {
+			return fmt.Errorf("failed to create vector index for %s: %v", label, err)
+		}
+	}
+	r.vectorIndexes.Store(dim, struct{}{})
+	return nil
+}
+
+// setEmbedding stores vector on the node with the given label whose
+// properties match key.
+func setEmbedding(ctx context.Context, tx neo4j.ManagedTransaction,
+	label string, key map[string]interface{}, modelID string, vector []float32,
+) error {
+	vectorProp, modelProp := embeddingProperties(len(vector))
+	params := map[string]interface{}{
+		"vector":   toFloat64s(vector),
+		"model_id": modelID,
+	}
+	match := make([]string, 0, len(key))
+	for prop, value := range key {
+		match = append(match, fmt.Sprintf("%s: $key_%s", prop, prop))
+		params["key_"+prop] = value
+	}
+	sort.Strings(match)
+	query := fmt.Sprintf(`
+		MATCH (n:%s {%s})
+		SET n.%s = $vector, n.%s = $model_id
+	`, label, strings.Join(match, ", "), vectorProp, modelProp)
+	_, err := tx.Run(ctx, query, params)
+	return err
+}
+
+func toFloat64s(vector []float32) []float64 {
+	out := make([]float64, len(vector))
+	for i, v := range vector {
+		out[i] = float64(v)
+	}
+	return out
+}
+
+func (r *MemoryRepository) ListTenantEntities(ctx context.Context, tenantID uint64, limit int) ([]*types.MemoryEntity, error) {
+	return r.listEntities(ctx, `
+		MATCH (e:Episode {tenant_id: $tenant_id})-[:MENTIONS]->(n:Entity)
+		WITH n, count(DISTINCT e) AS mentions
+		RETURN n.name AS name, n.type AS type, n.description AS description,
+			coalesce(n.aliases, []) AS aliases, mentions
+		ORDER BY mentions DESC, name
+		LIMIT $limit
+	`, map[string]interface{}{
+		"tenant_id": int64(tenantID),
+		"limit":     limit,
+	})
+}
+
+func (r *MemoryRepository) ListUserEntities(ctx context.Context, tenantID uint64, userID string) ([]*types.MemoryEntity, error) {
+	return r.listEntities(ctx, `
+		MATCH (e:Episode {tenant_id: $tenant_id, user_id: $user_id})-[:MENTIONS]->(n:Entity)
+		WITH n, count(DISTINCT e) AS mentions
+		RETURN n.name AS name, n.type AS type, n.description AS description,
+			coalesce(n.aliases, []) AS aliases, mentions
+		ORDER BY mentions DESC, name
+	`, map[string]interface{}{"tenant_id": int64(tenantID), "user_id": userID})
+}
+
+// listEntities runs a query returning entity rows (name, type, description,
+// aliases, mentions).
+func (r *MemoryRepository) listEntities(ctx context.Context, query string, params map[string]interface{}) ([]*types.MemoryEntity, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		res, err := tx.Run(ctx, query, params)
 		if err != nil {
 			return nil, err
 		}
 
-		var episodes []*types.Episode
+		var entities []*types.MemoryEntity
 		for res.Next(ctx) {
 			record := res.Record()
-			node, _ := record.Get("e")
-			episodeNode := node.(neo4j.Node)
+			entity := &types.MemoryEntity{}
+			if v, ok := record.Get("name"); ok {
+				entity.Name, _ = v.(string)
+			}
+			if v, ok := record.Get("type"); ok {
+				entity.Type, _ = v.(string)
+			}
+			if v, ok := record.Get("description"); ok {
+				entity.Description, _ = v.(string)
+			}
+			if v, ok := record.Get("aliases"); ok {
+				aliases, _ := v.([]interface{})
+				for _, alias := range aliases {
+					if a, ok := alias.(string); ok {
+						entity.Aliases = append(entity.Aliases, a)
+					}
+				}
+			}
+			if v, ok := record.Get("mentions"); ok {
+				mentions, _ := v.(int64)
+				entity.Mentions = int(mentions)
+			}
+			if entity.Name != "" {
+				entities = append(entities, entity)
+			}
+		}
+		return entities, res.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result.([]*types.MemoryEntity), nil
+}
 
-			createdAtStr := episodeNode.Props["created_at"].(string)
-			createdAt, _ := time.Parse(time.RFC3339, createdAtStr)
+// relationshipReturn is the RETURN clause of listRelationships queries.
+const relationshipReturn = `
+	RETURN elementId(r) AS id, s.name AS source, t.name AS target,
+		r.description AS description, r.weight AS weight,
+		r.valid_from AS valid_from, r.valid_to AS valid_to
+`
 
-			episodes = append(episodes, &types.Episode{
-				ID:        episodeNode.Props["id"].(string),
-				UserID:    episodeNode.Props["user_id"].(string),
-				SessionID: episodeNode.Props["session_id"].(string),
-				Summary:   episodeNode.Props["summary"].(string),
-				CreatedAt: createdAt,
-			})
-		}
-		return episodes, nil
+func (r *MemoryRepository) ListActiveRelationships(ctx context.Context, tenantID uint64, userID string, names []string, limit int) ([]*types.MemoryRelationship, error) {
+	if len(names) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	return r.listRelationships(ctx, `
+		MATCH (n:Entity {tenant_id: $tenant_id})
+		WHERE n.name IN $names OR any(alias IN coalesce(n.aliases, []) WHERE alias IN $names)
+		MATCH (n)-[r:RELATED_TO]-(:Entity)
+		WHERE r.valid_to IS NULL AND coalesce(r.user_id, $user_id) = $user_id
+		WITH DISTINCT r
+		MATCH (s:Entity)-[r]->(t:Entity)
+		WITH s, r, t
+		ORDER BY r.valid_from DESC
+		LIMIT $limit
+	`, map[string]interface{}{
+		"tenant_id": int64(tenantID),
+		"user_id":   userID,
+		"names":     names,
+		"limit":     limit,
+	})
+}
+
+func (r *MemoryRepository) InvalidateRelationships(ctx context.Context, episode *types.Episode, relationshipIDs []string) error {
+	if len(relationshipIDs) == 0 {
+		return nil
+	}
+	return r.write(ctx, `
+		MATCH (:Entity {tenant_id: $tenant_id})-[r:RELATED_TO]->()
+		WHERE elementId(r) IN $ids AND r.valid_to IS NULL
+		SET r.valid_to = $valid_to,
+			r.invalidated_by = $episode_id
+	`, map[string]interface{}{
+		"tenant_id":  int64(episode.TenantID),
+		"ids":        relationshipIDs,
+		"valid_to":   episode.CreatedAt.Format(time.RFC3339),
+		"episode_id": episode.ID,
 	})
+}
+
+func (r *MemoryRepository) ListUserRelationships(ctx context.Context, tenantID uint64, userID string) ([]*types.MemoryRelationship, error) {
+	return r.listRelationships(ctx, `
+		MATCH (e:Episode {tenant_id: $tenant_id, user_id: $user_id})-[:MENTIONS]->(n:Entity)
+		WITH collect(DISTINCT n) AS entities
+		UNWIND entities AS s
+		MATCH (s)-[r:RELATED_TO]->(t:Entity)
+		WHERE t IN entities
+		WITH s, r, t
+		ORDER BY s.name, t.name, r.valid_from
+	`, map[string]interface{}{"tenant_id": int64(tenantID), "user_id": userID})
+}
+
+// listRelationships runs a query ending in relationshipReturn.
+func (r *MemoryRepository) listRelationships(ctx context.Context, query string, params map[string]interface{}) ([]*types.MemoryRelationship, error) {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		res, err := tx.Run(ctx, query+relationshipReturn, params)
+		if err != nil {
+			return nil, err
+		}
 
+		var relationships []*types.MemoryRelationship
+		for res.Next(ctx) {
+			record := res.Record()
+			rel := &types.MemoryRelationship{}
+			if v, ok := record.Get("id"); ok {
+				rel.ID, _ = v.(string)
+			}
+			if v, ok := record.Get("source"); ok {
+				rel.Source, _ = v.(string)
+			}
+			if v, ok := record.Get("target"); ok {
+				rel.Target, _ = v.(string)
+			}
+			if v, ok := record.Get("description"); ok {
+				rel.Description, _ = v.(string)
+			}
+			if v, ok := record.Get("weight"); ok {
+				rel.Weight, _ = v.(float64)
+			}
+			if v, ok := record.Get("valid_from"); ok {
+				if from, ok := v.(string); ok {
+					rel.ValidFrom, _ = time.Parse(time.RFC3339, from)
+				}
+			}
+			if v, ok := record.Get("valid_to"); ok {
+				if to, ok := v.(string); ok {
+					if t, err := time.Parse(time.RFC3339, to); err == nil {
+						rel.ValidTo = &t
+					}
+				}
+			}
+			relationships = append(relationships, rel)
+		}
+		return relationships, res.Err()
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return result.([]*types.Episode), nil
+	return result.([]*types.MemoryRelationship), nil
+}
+
+func (r *MemoryRepository) UpdateEntity(ctx context.Context, tenantID uint64, userID string, entity *types.MemoryEntity) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		res, err := tx.Run(ctx, `
+			MATCH (e:Episode {tenant_id: $tenant_id, user_id: $user_id})-[:MENTIONS]->(n:Entity {tenant_id: $tenant_id, name: $name})
+			WITH DISTINCT n
+			SET n.type = $type,
+				n.description = $description
+			RETURN count(n) AS updated
+		`, map[string]interface{}{
+			"tenant_id":   int64(tenantID),
+			"user_id":     userID,
+			"name":        entity.Name,
+			"type":        entity.Type,
+			"description": entity.Description,
+		})
+		if err != nil {
+			return nil, err
+		}
+		var updated int64
+		if res.Next(ctx) {
+			v, _ := res.Record().Get("updated")
+			updated, _ = v.(int64)
+		}
+		return updated, res.Err()
+	})
+	if err != nil {
+		return err
+	}
+	if result.(int64) == 0 {
+		return types.ErrMemoryNotFound
+	}
+
+	return nil
+}
+
+func (r *MemoryRepository) MergeEntities(ctx context.Context, tenantID uint64, canonical string, duplicates []string) error {
+	session := r.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeWrite})
+	defer session.Close(ctx)
+
+	// Each duplicate is folded in four steps: move its mentions, move its
+	// outgoing and incoming relationships with their validity (dropping
+	// those that would become self loops), then record its names as
+	// aliases and delete it.
+	mergeQueries := []string{
+		`
+			MATCH (d:Entity {tenant_id: $tenant_id, name: $duplicate}), (c:Entity {tenant_id: $tenant_id, name: $canonical})
+			MATCH (e:Episode)-[:MENTIONS]->(d)
+			MERGE (e)-[:MENTIONS]->(c)
+		`,
+		`
+			MATCH (d:Entity {tenant_id: $tenant_id, name: $duplicate})-[r:RELATED_TO]->(t:Entity), (c:Entity {tenant_id: $tenant_id, name: $canonical})
+			WHERE t <> c
+			OPTIONAL MATCH (c)-[existing:RELATED_TO {description: r.description}]->(t)
+			WHERE existing.valid_from = r.valid_from OR (existing.valid_from IS NULL AND r.valid_from IS NULL)
+			FOREACH (_ IN CASE WHEN existing IS NULL THEN [1] ELSE [] END |
+				CREATE (c)-[nr:RELATED_TO]->(t) SET nr = properties(r))
+		`,
+		`
+			MATCH (s:Entity)-[r:RELATED_TO]->(d:Entity {tenant_id: $tenant_id, name: $duplicate}), (c:Entity {tenant_id: $tenant_id, name: $canonical})
+			WHERE s <> c
+			OPTIONAL MATCH (s)-[existing:RELATED_TO {description: r.description}]->(c)
+			WHERE existing.valid_from = r.valid_from OR (existing.valid_from IS NULL AND r.valid_from IS NULL)
+			FOREACH (_ IN CASE WHEN existing IS NULL THEN [1] ELSE [] END |
+				CREATE (s)-[nr:RELATED_TO]->(c) SET nr = properties(r))
+		`,
+		`
+			MATCH (d:Entity {tenant_id: $tenant_id, name: $duplicate}), (c:Entity {tenant_id: $tenant_id, name: $canonical})
+			SET c.aliases = reduce(acc = [], a IN coalesce(c.aliases, []) + [d.name] + coalesce(d.aliases, []) |
+					CASE WHEN a = c.name OR a IN acc THEN acc ELSE acc + a END),
+				c.description = CASE WHEN coalesce(c.description, '') = '' THEN d.description ELSE c.description END
+			DETACH DELETE d
+		`,
+	}
+
+	_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		res, err := tx.Run(ctx, `MATCH (c:Entity {tenant_id: $tenant_id, name: $canonical}) RETURN count(c) AS n`,
+			map[string]interface{}{"tenant_id": int64(tenantID), "canonical": canonical})
+		if err != nil {
+			return nil, err
+		}
+		var found int64
+		if res.Next(ctx) {
+			n, _ := res.Record().Get("n")
+			found, _ = n.(int64)
+		}
+		if found == 0 {
+			return nil, fmt.Errorf("entity %s not found", canonical)
+		}
+
+		for _, duplicate := range duplicates {
+			if duplicate == canonical {
+				continue
+			}
+			params := map[string]interface{}{
+				"tenant_id": int64(tenantID),
+				"canonical": canonical,
+				"duplicate": duplicate,
+			}
+			for _, query := range mergeQueries {
+				if _, err := tx.Run(ctx, query, params); err != nil {
+					return nil, fmt.Errorf("failed to merge entity %s into %s: %v", duplicate, canonical, err)
+				}
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "failed to merge entities: %v", err)
+		return err
+	}
+
+	return nil
 }
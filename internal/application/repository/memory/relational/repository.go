@@ -0,0 +1,898 @@
+// Package relational stores the memory graph in the application database,
+// PostgreSQL or SQLite, so memory works without a Neo4j deployment.
+package relational
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// semanticMinScore is the lowest score, cosine similarity mapped onto
+	// [0, 1] as by the Neo4j vector index, at which an episode counts as
+	// related.
+	semanticMinScore = 0.75
+	// relatedEntityHops is how many valid relationships FindRelatedEpisodes
+	// follows from the keyword entities, as the Neo4j backend does.
+	relatedEntityHops = 1
+)
+
+// MemoryRepository implements interfaces.MemoryRepository on adjacency
+// tables. Graph traversal uses recursive CTEs; semantic search scores the
+// user's stored vectors in process, which stays cheap as long as the
+// forgetting policy bounds the episodes kept per user.
+type MemoryRepository struct {
+	db *gorm.DB
+}
+
+func NewMemoryRepository(db *gorm.DB) interfaces.MemoryRepository {
+	return &MemoryRepository{db: db}
+}
+
+func (r *MemoryRepository) IsAvailable(ctx context.Context) bool {
+	return r.db != nil
+}
+
+func (r *MemoryRepository) SaveEpisode(ctx context.Context, episode *types.Episode, entities []*types.Entity, relations []*types.Relationship) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 1. Create the episode
+		row := &episodeRow{
+			ID:        episode.ID,
+			TenantID:  episode.TenantID,
+			UserID:    episode.UserID,
+			SessionID: episode.SessionID,
+			Summary:   episode.Summary,
+			Salience:  episode.Salience,
+			CreatedAt: episode.CreatedAt.UTC(),
+		}
+		embeddings := episode.Embeddings
+		if embeddings != nil && len(embeddings.Summary) > 0 {
+			row.EmbeddingModel = embeddings.ModelID
+			row.Embedding = encodeVector(embeddings.Summary)
+		}
+		err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"tenant_id", "user_id", "session_id", "summary", "salience",
+				"embedding_model", "embedding", "created_at",
+			}),
+		}).Create(row).Error
+		if err != nil {
+			return fmt.Errorf("failed to create episode: %v", err)
+		}
+
+		// Entities merged away by consolidation live on as aliases; file new
+		// mentions under the surviving entity instead of recreating them.
+		canonical, err := resolveAliases(tx, episode.TenantID, entities, relations)
+		if err != nil {
+			return err
+		}
+
+		// 2. Create entities and mentions. Entities are merged by name
+		// within the tenant only.
+		for _, entity := range entities {
+			name := canonical(entity.Title)
+			if name == "" {
+				continue
+			}
+			entityRow := &entityRow{
+				TenantID:    episode.TenantID,
+				Name:        name,
+				Type:        entity.Type,
+				Description: entity.Description,
+			}
+			updates := []string{"type", "description"}
+			if embeddings != nil && len(embeddings.Entities[entity.Title]) > 0 {
+				entityRow.EmbeddingModel = embeddings.ModelID
+				entityRow.Embedding = encodeVector(embeddings.Entities[entity.Title])
+				updates = append(updates, "embedding_model", "embedding")
+			}
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "name"}},
+				DoUpdates: clause.AssignmentColumns(updates),
+			}).Create(entityRow).Error
+			if err != nil {
+				return fmt.Errorf("failed to create entity %s: %v", entity.Title, err)
+			}
+			err = tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&mentionRow{EpisodeID: episode.ID, EntityName: name, TenantID: episode.TenantID}).Error
+			if err != nil {
+				return fmt.Errorf("failed to create mention of %s: %v", entity.Title, err)
+			}
+		}
+
+		// 3. Create relationships. A relationship that still holds is
+		// reaffirmed; otherwise a new one is recorded, valid from this
+		// episode on, leaving invalidated ones as history.
+		validFrom := episode.CreatedAt.UTC()
+		for _, rel := range relations {
+			source, target := canonical(rel.Source), canonical(rel.Target)
+			var endpoints int64
+			if err := tx.Model(&entityRow{}).Where("tenant_id = ? AND name IN ?", episode.TenantID, []string{source, target}).
+				Count(&endpoints).Error; err != nil {
+				return fmt.Errorf("failed to look up entities %s and %s: %v", rel.Source, rel.Target, err)
+			}
+			if (source == target && endpoints < 1) || (source != target && endpoints < 2) {
+				continue
+			}
+
+			res := tx.Model(&relationshipRow{}).
+				Where("tenant_id = ? AND source = ? AND target = ? AND description = ? AND valid_to IS NULL",
+					episode.TenantID, source, target, rel.Description).
+				Update("weight", rel.Weight)
+			if res.Error != nil {
+				return fmt.Errorf("failed to update relationship between %s and %s: %v", rel.Source, rel.Target, res.Error)
+			}
+			if res.RowsAffected > 0 {
+				continue
+			}
+			err := tx.Create(&relationshipRow{
+				ID:          uuid.New().String(),
+				TenantID:    episode.TenantID,
+				Source:      source,
+				Target:      target,
+				Description: rel.Description,
+				Weight:      rel.Weight,
+				UserID:      episode.UserID,
+				EpisodeID:   episode.ID,
+				ValidFrom:   &validFrom,
+			}).Error
+			if err != nil {
+				return fmt.Errorf("failed to create relationship between %s and %s: %v", rel.Source, rel.Target, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "failed to save episode: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// resolveAliases maps the names used by an episode to the entities they
+// were merged into within the tenant. Names that are not an alias map to
+// themselves.
+func resolveAliases(tx *gorm.DB, tenantID uint64,
+	entities []*types.Entity, relations []*types.Relationship,
+) (func(string) string, error) {
+	names := make([]string, 0, len(entities)+2*len(relations))
+	for _, entity := range entities {
+		names = append(names, entity.Title)
+	}
+	for _, rel := range relations {
+		names = append(names, rel.Source, rel.Target)
+	}
+	aliases := make(map[string]string)
+	if len(names) > 0 {
+		var rows []aliasRow
+		if err := tx.Where("tenant_id = ? AND alias IN ?", tenantID, names).Find(&rows).Error; err != nil {
+			return nil, fmt.Errorf("failed to resolve entity aliases: %v", err)
+		}
+		for _, row := range rows {
+			aliases[row.Alias] = row.EntityName
+		}
+	}
+	return func(name string) string {
+		if target := aliases[name]; target != "" {
+			return target
+		}
+		return name
+	}, nil
+}
+
+func (r *MemoryRepository) FindRelatedEpisodes(ctx context.Context, tenantID uint64, userID string, keywords []string, asOf time.Time, limit int) ([]*types.Episode, error) {
+	if len(keywords) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	if asOf.IsZero() {
+		asOf = time.Now()
+	}
+
+	// reachable walks relationships valid at as_of out from the keyword
+	// entities; episodes mentioning a keyword entity (depth 0) rank first.
+	// Names are cast to TEXT because PostgreSQL requires the recursive and
+	// non-recursive terms to have exactly the same column types.
+	var ids []string
+	err := r.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE reachable(name, depth) AS (
+			SELECT CAST(name AS TEXT), 0 FROM memory_entities
+			WHERE tenant_id = @tenant_id AND name IN @keywords
+			UNION
+			SELECT CAST(entity_name AS TEXT), 0 FROM memory_entity_aliases
+			WHERE tenant_id = @tenant_id AND alias IN @keywords
+			UNION
+			SELECT CAST(CASE WHEN rel.source = g.name THEN rel.target ELSE rel.source END AS TEXT), g.depth + 1
+			FROM reachable g
+			JOIN memory_relationships rel ON rel.source = g.name OR rel.target = g.name
+			WHERE g.depth < @hops AND rel.tenant_id = @tenant_id
+				AND (rel.valid_from IS NULL OR rel.valid_from <= @as_of)
+				AND (rel.valid_to IS NULL OR rel.valid_to > @as_of)
+		)
+		SELECT e.id
+		FROM memory_episodes e
+		JOIN memory_mentions m ON m.episode_id = e.id
+		JOIN reachable g ON g.name = m.entity_name
+		WHERE e.tenant_id = @tenant_id AND e.user_id = @user_id
+			AND e.archived_at IS NULL AND e.created_at <= @as_of
+		GROUP BY e.id, e.created_at
+		ORDER BY MIN(g.depth), e.created_at DESC
+		LIMIT @limit
+	`, map[string]interface{}{
+		"tenant_id": tenantID,
+		"keywords":  keywords,
+		"hops":      relatedEntityHops,
+		"as_of":     asOf.UTC(),
+		"user_id":   userID,
+		"limit":     limit,
+	}).Scan(&ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return r.loadEpisodes(ctx, ids)
+}
+
+func (r *MemoryRepository) FindSimilarEpisodes(ctx context.Context, tenantID uint64, userID string, modelID string, vector []float32, limit int) ([]*types.Episode, error) {
+	if len(vector) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	db := r.db.WithContext(ctx)
+
+	// An episode scores the best of its summary and the entities it
+	// mentions.
+	scores := make(map[string]float64)
+	var summaries []struct {
+		ID        string
+		Embedding string
+	}
+	err := db.Model(&episodeRow{}).Select("id, embedding").
+		Where("tenant_id = ? AND user_id = ? AND archived_at IS NULL AND embedding_model = ? AND embedding <> ''",
+			tenantID, userID, modelID).
+		Scan(&summaries).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range summaries {
+		if score, ok := vectorScore(vector, decodeVector(s.Embedding)); ok && score > scores[s.ID] {
+			scores[s.ID] = score
+		}
+	}
+
+	var mentions []struct {
+		EpisodeID string
+		Name      string
+		Embedding string
+	}
+	err = db.Raw(`
+		SELECT m.episode_id, n.name, n.embedding
+		FROM memory_mentions m
+		JOIN memory_episodes e ON e.id = m.episode_id
+		JOIN memory_entities n ON n.tenant_id = m.tenant_id AND n.name = m.entity_name
+		WHERE e.tenant_id = ? AND e.user_id = ? AND e.archived_at IS NULL
+			AND n.embedding_model = ? AND n.embedding <> ''
+	`, tenantID, userID, modelID).Scan(&mentions).Error
+	if err != nil {
+		return nil, err
+	}
+	entityScores := make(map[string]float64)
+	for _, m := range mentions {
+		score, seen := entityScores[m.Name]
+		if !seen {
+			score, _ = vectorScore(vector, decodeVector(m.Embedding))
+			entityScores[m.Name] = score
+		}
+		if score > scores[m.EpisodeID] {
+			scores[m.EpisodeID] = score
+		}
+	}
+
+	ids := make([]string, 0, len(scores))
+	for id, score := range scores {
+		if score >= semanticMinScore {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	return r.loadEpisodes(ctx, ids)
+}
+
+// vectorScore maps the cosine similarity of a and b onto [0, 1]. It reports
+// false when the vectors cannot be compared.
+func vectorScore(a, b []float32) (float64, bool) {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0, false
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0, false
+	}
+	return (1 + dot/(math.Sqrt(normA)*math.Sqrt(normB))) / 2, true
+}
+
+// loadEpisodes reads the episodes with the given IDs, in that order.
+func (r *MemoryRepository) loadEpisodes(ctx context.Context, ids []string) ([]*types.Episode, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	var rows []*episodeRow
+	if err := r.db.WithContext(ctx).Select(episodeColumns).Where("id IN ?", ids).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*episodeRow, len(rows))
+	for _, row := range rows {
+		byID[row.ID] = row
+	}
+	episodes := make([]*types.Episode, 0, len(rows))
+	for _, id := range ids {
+		if row, ok := byID[id]; ok {
+			episodes = append(episodes, row.toEpisode())
+		}
+	}
+	return episodes, nil
+}
+
+func (r *MemoryRepository) SaveProfileFacts(ctx context.Context, tenantID uint64, userID string, episodeID string, facts []*types.UserProfileFact) error {
+	if len(facts) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Facts keep a link to every episode that stated them, as
+		// provenance.
+		var episodes int64
+		if err := tx.Model(&episodeRow{}).Where("id = ? AND tenant_id = ?", episodeID, tenantID).
+			Count(&episodes).Error; err != nil {
+			return fmt.Errorf("failed to look up episode: %v", err)
+		}
+
+		for _, fact := range facts {
+			if fact.Value == "" {
+				for _, model := range []interface{}{&factSourceRow{}, &profileFactRow{}} {
+					err := tx.Where("tenant_id = ? AND user_id = ? AND category = ? AND fact_key = ?",
+						tenantID, userID, fact.Category, fact.Key).
+						Delete(model).Error
+					if err != nil {
+						return fmt.Errorf("failed to remove profile facts: %v", err)
+					}
+				}
+				continue
+			}
+
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "user_id"}, {Name: "category"}, {Name: "fact_key"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+			}).Create(&profileFactRow{
+				TenantID:  tenantID,
+				UserID:    userID,
+				Category:  fact.Category,
+				Key:       fact.Key,
+				Value:     fact.Value,
+				UpdatedAt: now,
+			}).Error
+			if err != nil {
+				return fmt.Errorf("failed to save profile facts: %v", err)
+			}
+			if episodes == 0 {
+				continue
+			}
+			err = tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&factSourceRow{
+				TenantID:  tenantID,
+				UserID:    userID,
+				Category:  fact.Category,
+				Key:       fact.Key,
+				EpisodeID: episodeID,
+			}).Error
+			if err != nil {
+				return fmt.Errorf("failed to save profile facts: %v", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "failed to save profile facts: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+func (r *MemoryRepository) GetProfileFacts(ctx context.Context, tenantID uint64, userID string) ([]*types.UserProfileFact, error) {
+	db := r.db.WithContext(ctx)
+	var rows []*profileFactRow
+	err := db.Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Order("category, fact_key").Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	var sources []*factSourceRow
+	err = db.Where("tenant_id = ? AND user_id = ?", tenantID, userID).Order("episode_id").Find(&sources).Error
+	if err != nil {
+		return nil, err
+	}
+
+	facts := make([]*types.UserProfileFact, len(rows))
+	byKey := make(map[[2]string]*types.UserProfileFact, len(rows))
+	for i, row := range rows {
+		facts[i] = &types.UserProfileFact{
+			Category:  row.Category,
+			Key:       row.Key,
+			Value:     row.Value,
+			UpdatedAt: row.UpdatedAt,
+		}
+		byKey[[2]string{row.Category, row.Key}] = facts[i]
+	}
+	for _, source := range sources {
+		if fact, ok := byKey[[2]string{source.Category, source.Key}]; ok {
+			fact.SourceEpisodeIDs = append(fact.SourceEpisodeIDs, source.EpisodeID)
+		}
+	}
+	return facts, nil
+}
+
+func (r *MemoryRepository) GetSessionSummary(ctx context.Context, tenantID uint64, sessionID string) (*types.SessionSummary, error) {
+	var rows []*sessionSummaryRow
+	err := r.db.WithContext(ctx).Where("tenant_id = ? AND session_id = ?", tenantID, sessionID).
+		Limit(1).Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	row := rows[0]
+	summary := &types.SessionSummary{
+		TenantID:     row.TenantID,
+		SessionID:    row.SessionID,
+		UserID:       row.UserID,
+		Summary:      row.Summary,
+		MessageCount: row.MessageCount,
+		UpdatedAt:    row.UpdatedAt,
+	}
+	if row.SummarizedUntil != nil {
+		summary.SummarizedUntil = *row.SummarizedUntil
+	}
+	return summary, nil
+}
+
+func (r *MemoryRepository) SaveSessionSummary(ctx context.Context, summary *types.SessionSummary) error {
+	row := &sessionSummaryRow{
+		SessionID:    summary.SessionID,
+		TenantID:     summary.TenantID,
+		UserID:       summary.UserID,
+		Summary:      summary.Summary,
+		MessageCount: summary.MessageCount,
+		UpdatedAt:    summary.UpdatedAt.UTC(),
+	}
+	if !summary.SummarizedUntil.IsZero() {
+		until := summary.SummarizedUntil.UTC()
+		row.SummarizedUntil = &until
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "session_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"tenant_id", "user_id", "summary", "summarized_until", "message_count", "updated_at",
+		}),
+	}).Create(row).Error
+}
+
+func (r *MemoryRepository) RecordEpisodeAccess(ctx context.Context, tenantID uint64, episodeIDs []string) error {
+	if len(episodeIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&episodeRow{}).
+		Where("tenant_id = ? AND id IN ?", tenantID, episodeIDs).
+		Updates(map[string]interface{}{
+			"access_count":     gorm.Expr("access_count + 1"),
+			"last_accessed_at": time.Now().UTC(),
+		}).Error
+}
+
+func (r *MemoryRepository) ListMemoryUsers(ctx context.Context) ([]types.MemoryUser, error) {
+	var users []types.MemoryUser
+	err := r.db.WithContext(ctx).Model(&episodeRow{}).
+		Distinct("tenant_id", "user_id").
+		Where("archived_at IS NULL AND user_id <> ''").
+		Scan(&users).Error
+	return users, err
+}
+
+func (r *MemoryRepository) ListUserEpisodes(ctx context.Context, tenantID uint64, userID string) ([]*types.Episode, error) {
+	var rows []*episodeRow
+	err := r.db.WithContext(ctx).Select(episodeColumns).
+		Where("tenant_id = ? AND user_id = ? AND archived_at IS NULL", tenantID, userID).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	episodes := make([]*types.Episode, len(rows))
+	for i, row := range rows {
+		episodes[i] = row.toEpisode()
+	}
+	return episodes, nil
+}
+
+func (r *MemoryRepository) ListEpisodes(ctx context.Context, tenantID uint64, userID string, offset int, limit int) ([]*types.Episode, int64, error) {
+	db := r.db.WithContext(ctx)
+	var total int64
+	if err := db.Model(&episodeRow{}).Where("tenant_id = ? AND user_id = ?", tenantID, userID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var rows []*episodeRow
+	err := db.Select(episodeColumns).
+		Where("tenant_id = ? AND user_id = ?", tenantID, userID).
+		Order("created_at DESC").
+		Offset(offset).Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	episodes := make([]*types.Episode, len(rows))
+	for i, row := range rows {
+		episodes[i] = row.toEpisode()
+	}
+	return episodes, total, nil
+}
+
+func (r *MemoryRepository) DeleteEpisode(ctx context.Context, tenantID uint64, userID string, episodeID string) error {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&episodeRow{}).
+		Where("id = ? AND tenant_id = ? AND user_id = ?", episodeID, tenantID, userID).
+		Count(&n).Error
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return types.ErrMemoryNotFound
+	}
+
+	return r.DeleteEpisodes(ctx, tenantID, []string{episodeID})
+}
+
+func (r *MemoryRepository) ArchiveEpisodes(ctx context.Context, tenantID uint64, episodeIDs []string) error {
+	if len(episodeIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&episodeRow{}).
+		Where("tenant_id = ? AND id IN ?", tenantID, episodeIDs).
+		Update("archived_at", time.Now().UTC()).Error
+}
+
+func (r *MemoryRepository) DeleteEpisodes(ctx context.Context, tenantID uint64, episodeIDs []string) error {
+	if len(episodeIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var ids []string
+		if err := tx.Model(&episodeRow{}).Where("tenant_id = ? AND id IN ?", tenantID, episodeIDs).
+			Pluck("id", &ids).Error; err != nil || len(ids) == 0 {
+			return err
+		}
+		var names []string
+		if err := tx.Model(&mentionRow{}).Where("episode_id IN ?", ids).
+			Distinct().Pluck("entity_name", &names).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("episode_id IN ?", ids).Delete(&mentionRow{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("episode_id IN ?", ids).Delete(&factSourceRow{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id IN ?", ids).Delete(&episodeRow{}).Error; err != nil {
+			return err
+		}
+		if len(names) == 0 {
+			return nil
+		}
+
+		// Entities no other episode mentions go too, with their edges.
+		var orphans []string
+		err := tx.Model(&entityRow{}).
+			Where("tenant_id = ? AND name IN ?", tenantID, names).
+			Where(`NOT EXISTS (SELECT 1 FROM memory_mentions m
+				WHERE m.tenant_id = memory_entities.tenant_id AND m.entity_name = memory_entities.name)`).
+			Pluck("name", &orphans).Error
+		if err != nil || len(orphans) == 0 {
+			return err
+		}
+		return deleteEntities(tx, tenantID, orphans)
+	})
+}
+
+// deleteEntities deletes the tenant's entities along with their
+// relationships and aliases.
+func deleteEntities(tx *gorm.DB, tenantID uint64, names []string) error {
+	if err := tx.Where("tenant_id = ? AND (source IN ? OR target IN ?)", tenantID, names, names).
+		Delete(&relationshipRow{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("tenant_id = ? AND entity_name IN ?", tenantID, names).Delete(&aliasRow{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("tenant_id = ? AND name IN ?", tenantID, names).Delete(&entityRow{}).Error
+}
+
+func (r *MemoryRepository) ListTenantEntities(ctx context.Context, tenantID uint64, limit int) ([]*types.MemoryEntity, error) {
+	return r.listEntities(ctx, tenantID, `
+		SELECT n.name, n.type, n.description, COUNT(DISTINCT e.id) AS mentions
+		FROM memory_entities n
+		JOIN memory_mentions m ON m.tenant_id = n.tenant_id AND m.entity_name = n.name
+		JOIN memory_episodes e ON e.id = m.episode_id
+		WHERE n.tenant_id = ?
+		GROUP BY n.name, n.type, n.description
+		ORDER BY mentions DESC, n.name
+		LIMIT ?
+	`, tenantID, limit)
+}
+
+func (r *MemoryRepository) ListUserEntities(ctx context.Context, tenantID uint64, userID string) ([]*types.MemoryEntity, error) {
+	return r.listEntities(ctx, tenantID, `
+		SELECT n.name, n.type, n.description, COUNT(DISTINCT e.id) AS mentions
+		FROM memory_entities n
+		JOIN memory_mentions m ON m.tenant_id = n.tenant_id AND m.entity_name = n.name
+		JOIN memory_episodes e ON e.id = m.episode_id
+		WHERE n.tenant_id = ? AND e.user_id = ?
+		GROUP BY n.name, n.type, n.description
+		ORDER BY mentions DESC, n.name
+	`, tenantID, userID)
+}
+
+// listEntities runs a query returning rows (name, type, description,
+// mentions) of the tenant's entities and attaches their aliases.
+func (r *MemoryRepository) listEntities(ctx context.Context, tenantID uint64, query string, args ...interface{}) ([]*types.MemoryEntity, error) {
+	db := r.db.WithContext(ctx)
+	var rows []struct {
+		Name        string
+		Type        string
+		Description string
+		Mentions    int
+	}
+	if err := db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	entities := make([]*types.MemoryEntity, len(rows))
+	names := make([]string, len(rows))
+	byName := make(map[string]*types.MemoryEntity, len(rows))
+	for i, row := range rows {
+		entities[i] = &types.MemoryEntity{
+			Name:        row.Name,
+			Type:        row.Type,
+			Description: row.Description,
+			Mentions:    row.Mentions,
+		}
+		names[i] = row.Name
+		byName[row.Name] = entities[i]
+	}
+	var aliases []aliasRow
+	err := db.Where("tenant_id = ? AND entity_name IN ?", tenantID, names).Order("alias").Find(&aliases).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, alias := range aliases {
+		if entity, ok := byName[alias.EntityName]; ok {
+			entity.Aliases = append(entity.Aliases, alias.Alias)
+		}
+	}
+	return entities, nil
+}
+
+func (r *MemoryRepository) UpdateEntity(ctx context.Context, tenantID uint64, userID string, entity *types.MemoryEntity) error {
+	db := r.db.WithContext(ctx)
+	var n int64
+	err := db.Table("memory_mentions m").
+		Joins("JOIN memory_episodes e ON e.id = m.episode_id").
+		Where("m.tenant_id = ? AND e.user_id = ? AND m.entity_name = ?", tenantID, userID, entity.Name).
+		Count(&n).Error
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return types.ErrMemoryNotFound
+	}
+
+	return db.Model(&entityRow{}).Where("tenant_id = ? AND name = ?", tenantID, entity.Name).
+		Updates(map[string]interface{}{"type": entity.Type, "description": entity.Description}).Error
+}
+
+func (r *MemoryRepository) ListActiveRelationships(ctx context.Context, tenantID uint64, userID string, names []string, limit int) ([]*types.MemoryRelationship, error) {
+	if len(names) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	db := r.db.WithContext(ctx)
+	canonical := db.Model(&aliasRow{}).Select("entity_name").Where("tenant_id = ? AND alias IN ?", tenantID, names)
+	var rows []*relationshipRow
+	err := db.Where("tenant_id = ? AND valid_to IS NULL AND user_id IN ?", tenantID, []string{userID, ""}).
+		Where("source IN ? OR target IN ? OR source IN (?) OR target IN (?)", names, names, canonical, canonical).
+		Order("valid_from DESC").
+		Limit(limit).
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return toRelationships(rows), nil
+}
+
+func (r *MemoryRepository) InvalidateRelationships(ctx context.Context, episode *types.Episode, relationshipIDs []string) error {
+	if len(relationshipIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Model(&relationshipRow{}).
+		Where("tenant_id = ? AND id IN ? AND valid_to IS NULL", episode.TenantID, relationshipIDs).
+		Updates(map[string]interface{}{
+			"valid_to":       episode.CreatedAt.UTC(),
+			"invalidated_by": episode.ID,
+		}).Error
+}
+
+func (r *MemoryRepository) ListUserRelationships(ctx context.Context, tenantID uint64, userID string) ([]*types.MemoryRelationship, error) {
+	db := r.db.WithContext(ctx)
+	entities := db.Table("memory_mentions m").
+		Select("m.entity_name").
+		Joins("JOIN memory_episodes e ON e.id = m.episode_id").
+		Where("m.tenant_id = ? AND e.user_id = ?", tenantID, userID)
+	var rows []*relationshipRow
+	err := db.Where("tenant_id = ? AND source IN (?) AND target IN (?)", tenantID, entities, entities).
+		Order("source, target, valid_from").
+		Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	return toRelationships(rows), nil
+}
+
+func toRelationships(rows []*relationshipRow) []*types.MemoryRelationship {
+	relationships := make([]*types.MemoryRelationship, len(rows))
+	for i, row := range rows {
+		relationships[i] = row.toRelationship()
+	}
+	return relationships
+}
+
+func (r *MemoryRepository) MergeEntities(ctx context.Context, tenantID uint64, canonical string, duplicates []string) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var target entityRow
+		if err := tx.Where("tenant_id = ? AND name = ?", tenantID, canonical).First(&target).Error; err != nil {
+			return fmt.Errorf("entity %s not found", canonical)
+		}
+
+		for _, duplicate := range duplicates {
+			if duplicate == canonical {
+				continue
+			}
+			var dup entityRow
+			err := tx.Where("tenant_id = ? AND name = ?", tenantID, duplicate).Limit(1).Find(&dup).Error
+			if err != nil {
+				return fmt.Errorf("failed to merge entity %s into %s: %v", duplicate, canonical, err)
+			}
+			if dup.Name == "" {
+				continue
+			}
+			if err := mergeEntity(tx, &target, &dup); err != nil {
+				return fmt.Errorf("failed to merge entity %s into %s: %v", duplicate, canonical, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "failed to merge entities: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// mergeEntity folds dup into canonical, both of the same tenant: its
+// mentions and relationships (dropping those that would become self loops or
+// duplicates) move over, its names become aliases of canonical and it is
+// deleted.
+func mergeEntity(tx *gorm.DB, canonical, dup *entityRow) error {
+	tenantID := canonical.TenantID
+	err := tx.Exec(`
+		INSERT INTO memory_mentions (episode_id, entity_name, tenant_id)
+		SELECT episode_id, ?, tenant_id FROM memory_mentions WHERE tenant_id = ? AND entity_name = ?
+		ON CONFLICT DO NOTHING
+	`, canonical.Name, tenantID, dup.Name).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Where("tenant_id = ? AND entity_name = ?", tenantID, dup.Name).Delete(&mentionRow{}).Error; err != nil {
+		return err
+	}
+
+	var rels []*relationshipRow
+	if err := tx.Where("tenant_id = ? AND (source = ? OR target = ?)", tenantID, dup.Name, dup.Name).
+		Find(&rels).Error; err != nil {
+		return err
+	}
+	for _, rel := range rels {
+		source, target := rel.Source, rel.Target
+		if source == dup.Name {
+			source = canonical.Name
+		}
+		if target == dup.Name {
+			target = canonical.Name
+		}
+		var existing int64
+		if source != target {
+			q := tx.Model(&relationshipRow{}).
+				Where("tenant_id = ? AND source = ? AND target = ? AND description = ?",
+					tenantID, source, target, rel.Description)
+			if rel.ValidFrom == nil {
+				q = q.Where("valid_from IS NULL")
+			} else {
+				q = q.Where("valid_from = ?", *rel.ValidFrom)
+			}
+			if err := q.Count(&existing).Error; err != nil {
+				return err
+			}
+		}
+		if source == target || existing > 0 {
+			if err := tx.Delete(&relationshipRow{}, "id = ?", rel.ID).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		err := tx.Model(&relationshipRow{}).Where("id = ?", rel.ID).
+			Updates(map[string]interface{}{"source": source, "target": target}).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(&aliasRow{}).Where("tenant_id = ? AND entity_name = ?", tenantID, dup.Name).
+		Update("entity_name", canonical.Name).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("tenant_id = ? AND alias = ?", tenantID, canonical.Name).Delete(&aliasRow{}).Error; err != nil {
+		return err
+	}
+	err = tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "alias"}},
+		DoUpdates: clause.AssignmentColumns([]string{"entity_name"}),
+	}).Create(&aliasRow{TenantID: tenantID, Alias: dup.Name, EntityName: canonical.Name}).Error
+	if err != nil {
+		return err
+	}
+	if canonical.Description == "" && dup.Description != "" {
+		canonical.Description = dup.Description
+		if err := tx.Model(&entityRow{}).Where("tenant_id = ? AND name = ?", tenantID, canonical.Name).
+			Update("description", dup.Description).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Where("tenant_id = ? AND name = ?", tenantID, dup.Name).Delete(&entityRow{}).Error
+}
@@ -0,0 +1,312 @@
+package relational
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// memoryTestDDL mirrors the memory graph tables of the sqlite init
+// migration, inlined like the other sqlite repository tests.
+const memoryTestDDL = `
+CREATE TABLE memory_episodes (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    user_id VARCHAR(36) NOT NULL,
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    summary TEXT NOT NULL DEFAULT '',
+    salience REAL NOT NULL DEFAULT 0,
+    access_count INTEGER NOT NULL DEFAULT 0,
+    last_accessed_at DATETIME NULL,
+    archived_at DATETIME NULL,
+    embedding_model VARCHAR(64) NOT NULL DEFAULT '',
+    embedding TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE memory_entities (
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    name VARCHAR(512) NOT NULL,
+    type VARCHAR(128) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    embedding_model VARCHAR(64) NOT NULL DEFAULT '',
+    embedding TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (tenant_id, name)
+);
+CREATE TABLE memory_entity_aliases (
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    alias VARCHAR(512) NOT NULL,
+    entity_name VARCHAR(512) NOT NULL,
+    PRIMARY KEY (tenant_id, alias)
+);
+CREATE TABLE memory_mentions (
+    episode_id VARCHAR(36) NOT NULL,
+    entity_name VARCHAR(512) NOT NULL,
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (episode_id, entity_name)
+);
+CREATE TABLE memory_relationships (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    source VARCHAR(512) NOT NULL,
+    target VARCHAR(512) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    weight REAL NOT NULL DEFAULT 0,
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    episode_id VARCHAR(36) NOT NULL DEFAULT '',
+    valid_from DATETIME NULL,
+    valid_to DATETIME NULL,
+    invalidated_by VARCHAR(36) NOT NULL DEFAULT ''
+);
+CREATE TABLE memory_profile_facts (
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    user_id VARCHAR(36) NOT NULL,
+    category VARCHAR(32) NOT NULL,
+    fact_key VARCHAR(128) NOT NULL,
+    value TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, user_id, category, fact_key)
+);
+CREATE TABLE memory_profile_fact_sources (
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    user_id VARCHAR(36) NOT NULL,
+    category VARCHAR(32) NOT NULL,
+    fact_key VARCHAR(128) NOT NULL,
+    episode_id VARCHAR(36) NOT NULL,
+    PRIMARY KEY (tenant_id, user_id, category, fact_key, episode_id)
+);
+CREATE TABLE memory_session_summaries (
+    session_id VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    summary TEXT NOT NULL DEFAULT '',
+    summarized_until DATETIME NULL,
+    message_count INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+func setupMemoryRepo(t *testing.T) *MemoryRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(memoryTestDDL).Error)
+	return NewMemoryRepository(db).(*MemoryRepository)
+}
+
+func saveEpisode(t *testing.T, repo *MemoryRepository, id string, at time.Time, entities []string, relations ...*types.Relationship) {
+	t.Helper()
+	saveTenantEpisode(t, repo, 1, id, at, entities, relations...)
+}
+
+func saveTenantEpisode(t *testing.T, repo *MemoryRepository, tenantID uint64, id string, at time.Time,
+	entities []string, relations ...*types.Relationship,
+) {
+	t.Helper()
+	var ents []*types.Entity
+	for _, name := range entities {
+		ents = append(ents, &types.Entity{Title: name, Type: "Thing"})
+	}
+	require.NoError(t, repo.SaveEpisode(context.Background(), &types.Episode{
+		ID: id, TenantID: tenantID, UserID: "u1", Summary: id, CreatedAt: at,
+	}, ents, relations))
+}
+
+func episodeIDs(episodes []*types.Episode) []string {
+	var ids []string
+	for _, ep := range episodes {
+		ids = append(ids, ep.ID)
+	}
+	return ids
+}
+
+func TestFindRelatedEpisodesAsOf(t *testing.T) {
+	repo := setupMemoryRepo(t)
+	ctx := context.Background()
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	mar := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	saveEpisode(t, repo, "tencent", jan.Add(-time.Hour), []string{"Tencent"})
+	saveEpisode(t, repo, "hired", jan, []string{"Lin", "Tencent"},
+		&types.Relationship{Source: "Lin", Target: "Tencent", Description: "works at", Weight: 1})
+	saveEpisode(t, repo, "lin", mar.Add(-time.Hour), []string{"Lin"})
+	saveEpisode(t, repo, "moved", mar, []string{"Lin", "Alibaba"},
+		&types.Relationship{Source: "Lin", Target: "Alibaba", Description: "works at", Weight: 1})
+
+	active, err := repo.ListActiveRelationships(ctx, 1, "u1", []string{"Lin"}, 10)
+	require.NoError(t, err)
+	require.Len(t, active, 2)
+	var tencent string
+	for _, rel := range active {
+		if rel.Target == "Tencent" {
+			tencent = rel.ID
+		}
+	}
+	require.NoError(t, repo.InvalidateRelationships(ctx, &types.Episode{ID: "moved", TenantID: 1, CreatedAt: mar}, []string{tencent}))
+
+	// Now Lin only relates to Alibaba, so the Tencent-only episode is not
+	// reached; direct mentions come first.
+	got, err := repo.FindRelatedEpisodes(ctx, 1, "u1", []string{"Lin"}, time.Time{}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"moved", "lin", "hired"}, episodeIDs(got))
+
+	// In February Lin worked at Tencent and the later episodes had not
+	// happened yet.
+	got, err = repo.FindRelatedEpisodes(ctx, 1, "u1", []string{"Lin"}, mar.Add(-30*24*time.Hour), 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hired", "tencent"}, episodeIDs(got))
+
+	rels, err := repo.ListUserRelationships(ctx, 1, "u1")
+	require.NoError(t, err)
+	require.Len(t, rels, 2)
+	for _, rel := range rels {
+		assert.Equal(t, rel.Target == "Tencent", rel.ValidTo != nil, "relationship to %s", rel.Target)
+	}
+}
+
+func TestFindSimilarEpisodes(t *testing.T) {
+	repo := setupMemoryRepo(t)
+	ctx := context.Background()
+	now := time.Now()
+	require.NoError(t, repo.SaveEpisode(ctx, &types.Episode{
+		ID: "a", TenantID: 1, UserID: "u1", CreatedAt: now,
+		Embeddings: &types.MemoryEmbeddings{ModelID: "emb", Summary: []float32{1, 0}},
+	}, nil, nil))
+	require.NoError(t, repo.SaveEpisode(ctx, &types.Episode{
+		ID: "b", TenantID: 1, UserID: "u1", CreatedAt: now,
+		Embeddings: &types.MemoryEmbeddings{
+			ModelID:  "emb",
+			Summary:  []float32{0, 1},
+			Entities: map[string][]float32{"Go": {0.9, 0.1}},
+		},
+	}, []*types.Entity{{Title: "Go"}}, nil))
+	require.NoError(t, repo.SaveEpisode(ctx, &types.Episode{
+		ID: "c", TenantID: 1, UserID: "u1", CreatedAt: now,
+		Embeddings: &types.MemoryEmbeddings{ModelID: "other", Summary: []float32{1, 0}},
+	}, nil, nil))
+
+	got, err := repo.FindSimilarEpisodes(ctx, 1, "u1", "emb", []float32{1, 0}, 5)
+	require.NoError(t, err)
+	// "b" matches through its entity; "c" was embedded by another model.
+	assert.Equal(t, []string{"a", "b"}, episodeIDs(got))
+}
+
+func TestMergeAndDeleteEntities(t *testing.T) {
+	repo := setupMemoryRepo(t)
+	ctx := context.Background()
+	now := time.Now()
+	saveEpisode(t, repo, "e1", now.Add(-3*time.Hour), []string{"Tencent", "Lin"},
+		&types.Relationship{Source: "Lin", Target: "Tencent", Description: "works at"})
+	saveEpisode(t, repo, "e2", now.Add(-2*time.Hour), []string{"Tencent Inc.", "Lin"},
+		&types.Relationship{Source: "Lin", Target: "Tencent Inc.", Description: "works at"})
+
+	require.NoError(t, repo.MergeEntities(ctx, 1, "Tencent", []string{"Tencent Inc."}))
+	entities, err := repo.ListUserEntities(ctx, 1, "u1")
+	require.NoError(t, err)
+	require.Len(t, entities, 2)
+	for _, entity := range entities {
+		assert.Equal(t, 2, entity.Mentions, entity.Name)
+		if entity.Name == "Tencent" {
+			assert.Equal(t, []string{"Tencent Inc."}, entity.Aliases)
+		}
+	}
+	rels, err := repo.ListUserRelationships(ctx, 1, "u1")
+	require.NoError(t, err)
+	assert.Len(t, rels, 2, "relationships with different validity are both kept")
+
+	// New mentions of the alias land on the canonical entity.
+	saveEpisode(t, repo, "e3", now.Add(-time.Hour), []string{"Tencent Inc."})
+	got, err := repo.FindRelatedEpisodes(ctx, 1, "u1", []string{"Tencent Inc."}, time.Time{}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"e3"}, episodeIDs(got))
+
+	require.NoError(t, repo.SaveProfileFacts(ctx, 1, "u1", "e1", []*types.UserProfileFact{
+		{Category: types.ProfileCategoryRole, Key: "job", Value: "engineer"},
+	}))
+	require.NoError(t, repo.DeleteEpisodes(ctx, 1, []string{"e1", "e2"}))
+	entities, err = repo.ListUserEntities(ctx, 1, "u1")
+	require.NoError(t, err)
+	require.Len(t, entities, 1)
+	assert.Equal(t, "Tencent", entities[0].Name)
+	facts, err := repo.GetProfileFacts(ctx, 1, "u1")
+	require.NoError(t, err)
+	require.Len(t, facts, 1)
+	assert.Empty(t, facts[0].SourceEpisodeIDs)
+
+	assert.ErrorIs(t, repo.DeleteEpisode(ctx, 1, "u2", "e3"), types.ErrMemoryNotFound)
+}
+
+func TestSessionSummary(t *testing.T) {
+	repo := setupMemoryRepo(t)
+	ctx := context.Background()
+
+	got, err := repo.GetSessionSummary(ctx, 1, "s1")
+	require.NoError(t, err)
+	assert.Nil(t, got)
+
+	until := time.Date(2026, 3, 1, 8, 30, 0, 123456000, time.UTC)
+	require.NoError(t, repo.SaveSessionSummary(ctx, &types.SessionSummary{
+		TenantID: 1, SessionID: "s1", UserID: "u1", Summary: "first", SummarizedUntil: until, MessageCount: 4, UpdatedAt: until,
+	}))
+	require.NoError(t, repo.SaveSessionSummary(ctx, &types.SessionSummary{
+		TenantID: 1, SessionID: "s1", UserID: "u1", Summary: "second", SummarizedUntil: until.Add(time.Minute),
+		MessageCount: 6, UpdatedAt: until.Add(time.Minute),
+	}))
+
+	got, err = repo.GetSessionSummary(ctx, 1, "s1")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "second", got.Summary)
+	assert.Equal(t, 6, got.MessageCount)
+	assert.True(t, got.SummarizedUntil.Equal(until.Add(time.Minute)), "summarized until %s", got.SummarizedUntil)
+}
+
+func TestTenantIsolation(t *testing.T) {
+	repo := setupMemoryRepo(t)
+	ctx := context.Background()
+	now := time.Now()
+	saveTenantEpisode(t, repo, 1, "t1", now.Add(-2*time.Hour), []string{"Lin", "Tencent"},
+		&types.Relationship{Source: "Lin", Target: "Tencent", Description: "works at", Weight: 1})
+	saveTenantEpisode(t, repo, 2, "t2", now.Add(-time.Hour), []string{"Lin", "Alibaba"},
+		&types.Relationship{Source: "Lin", Target: "Alibaba", Description: "works at", Weight: 1})
+
+	// The same user ID in another tenant sees none of tenant 1's graph.
+	got, err := repo.FindRelatedEpisodes(ctx, 2, "u1", []string{"Tencent"}, time.Time{}, 10)
+	require.NoError(t, err)
+	assert.Empty(t, got)
+	got, err = repo.FindRelatedEpisodes(ctx, 2, "u1", []string{"Lin"}, time.Time{}, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"t2"}, episodeIDs(got))
+	rels, err := repo.ListActiveRelationships(ctx, 2, "u1", []string{"Lin"}, 10)
+	require.NoError(t, err)
+	require.Len(t, rels, 1)
+	assert.Equal(t, "Alibaba", rels[0].Target)
+
+	// Each tenant has its own "Lin": editing or merging one leaves the
+	// other alone.
+	require.NoError(t, repo.UpdateEntity(ctx, 1, "u1", &types.MemoryEntity{Name: "Lin", Type: "Person"}))
+	require.NoError(t, repo.MergeEntities(ctx, 1, "Lin", []string{"Tencent"}))
+	entities, err := repo.ListUserEntities(ctx, 2, "u1")
+	require.NoError(t, err)
+	require.Len(t, entities, 2)
+	for _, entity := range entities {
+		assert.Equal(t, "Thing", entity.Type, entity.Name)
+		assert.Empty(t, entity.Aliases, entity.Name)
+	}
+
+	// Episodes of another tenant cannot be deleted or forgotten.
+	assert.ErrorIs(t, repo.DeleteEpisode(ctx, 1, "u1", "t2"), types.ErrMemoryNotFound)
+	require.NoError(t, repo.DeleteEpisodes(ctx, 1, []string{"t2"}))
+	episodes, err := repo.ListUserEpisodes(ctx, 2, "u1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"t2"}, episodeIDs(episodes))
+
+	users, err := repo.ListMemoryUsers(ctx)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []types.MemoryUser{{TenantID: 1, UserID: "u1"}, {TenantID: 2, UserID: "u1"}}, users)
+}
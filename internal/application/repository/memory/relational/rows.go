@@ -0,0 +1,168 @@
+package relational
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// The memory graph is stored as adjacency tables: episodes and entities are
+// the nodes, mentions and relationships the edges. Vectors are JSON arrays in
+// TEXT columns so the same schema works on PostgreSQL and SQLite. Every row
+// carries the tenant it belongs to; entities are keyed by tenant and name, so
+// tenants never share a node.
+
+type episodeRow struct {
+	ID             string     `gorm:"column:id;primaryKey"`
+	TenantID       uint64     `gorm:"column:tenant_id"`
+	UserID         string     `gorm:"column:user_id"`
+	SessionID      string     `gorm:"column:session_id"`
+	Summary        string     `gorm:"column:summary"`
+	Salience       float64    `gorm:"column:salience"`
+	AccessCount    int        `gorm:"column:access_count"`
+	LastAccessedAt *time.Time `gorm:"column:last_accessed_at"`
+	ArchivedAt     *time.Time `gorm:"column:archived_at"`
+	EmbeddingModel string     `gorm:"column:embedding_model"`
+	Embedding      string     `gorm:"column:embedding"`
+	CreatedAt      time.Time  `gorm:"column:created_at"`
+}
+
+func (episodeRow) TableName() string { return "memory_episodes" }
+
+// episodeColumns are the episode columns read back, leaving out the
+// embedding which can be large.
+var episodeColumns = []string{
+	"id", "tenant_id", "user_id", "session_id", "summary", "salience",
+	"access_count", "last_accessed_at", "archived_at", "created_at",
+}
+
+func (row *episodeRow) toEpisode() *types.Episode {
+	episode := &types.Episode{
+		ID:          row.ID,
+		TenantID:    row.TenantID,
+		UserID:      row.UserID,
+		SessionID:   row.SessionID,
+		Summary:     row.Summary,
+		CreatedAt:   row.CreatedAt,
+		Salience:    row.Salience,
+		AccessCount: row.AccessCount,
+		Archived:    row.ArchivedAt != nil,
+	}
+	if row.LastAccessedAt != nil {
+		episode.LastAccessedAt = *row.LastAccessedAt
+	}
+	return episode
+}
+
+type entityRow struct {
+	TenantID       uint64 `gorm:"column:tenant_id;primaryKey"`
+	Name           string `gorm:"column:name;primaryKey"`
+	Type           string `gorm:"column:type"`
+	Description    string `gorm:"column:description"`
+	EmbeddingModel string `gorm:"column:embedding_model"`
+	Embedding      string `gorm:"column:embedding"`
+}
+
+func (entityRow) TableName() string { return "memory_entities" }
+
+// aliasRow records the name of a duplicate merged into an entity.
+type aliasRow struct {
+	TenantID   uint64 `gorm:"column:tenant_id;primaryKey"`
+	Alias      string `gorm:"column:alias;primaryKey"`
+	EntityName string `gorm:"column:entity_name"`
+}
+
+func (aliasRow) TableName() string { return "memory_entity_aliases" }
+
+type mentionRow struct {
+	EpisodeID  string `gorm:"column:episode_id;primaryKey"`
+	EntityName string `gorm:"column:entity_name;primaryKey"`
+	TenantID   uint64 `gorm:"column:tenant_id"`
+}
+
+func (mentionRow) TableName() string { return "memory_mentions" }
+
+type relationshipRow struct {
+	ID            string     `gorm:"column:id;primaryKey"`
+	TenantID      uint64     `gorm:"column:tenant_id"`
+	Source        string     `gorm:"column:source"`
+	Target        string     `gorm:"column:target"`
+	Description   string     `gorm:"column:description"`
+	Weight        float64    `gorm:"column:weight"`
+	UserID        string     `gorm:"column:user_id"`
+	EpisodeID     string     `gorm:"column:episode_id"`
+	ValidFrom     *time.Time `gorm:"column:valid_from"`
+	ValidTo       *time.Time `gorm:"column:valid_to"`
+	InvalidatedBy string     `gorm:"column:invalidated_by"`
+}
+
+func (relationshipRow) TableName() string { return "memory_relationships" }
+
+func (row *relationshipRow) toRelationship() *types.MemoryRelationship {
+	rel := &types.MemoryRelationship{
+		ID:          row.ID,
+		Source:      row.Source,
+		Target:      row.Target,
+		Description: row.Description,
+		Weight:      row.Weight,
+		ValidTo:     row.ValidTo,
+	}
+	if row.ValidFrom != nil {
+		rel.ValidFrom = *row.ValidFrom
+	}
+	return rel
+}
+
+type profileFactRow struct {
+	TenantID  uint64    `gorm:"column:tenant_id;primaryKey"`
+	UserID    string    `gorm:"column:user_id;primaryKey"`
+	Category  string    `gorm:"column:category;primaryKey"`
+	Key       string    `gorm:"column:fact_key;primaryKey"`
+	Value     string    `gorm:"column:value"`
+	UpdatedAt time.Time `gorm:"column:updated_at"`
+}
+
+func (profileFactRow) TableName() string { return "memory_profile_facts" }
+
+// factSourceRow links a profile fact to an episode it was learned from.
+type factSourceRow struct {
+	TenantID  uint64 `gorm:"column:tenant_id;primaryKey"`
+	UserID    string `gorm:"column:user_id;primaryKey"`
+	Category  string `gorm:"column:category;primaryKey"`
+	Key       string `gorm:"column:fact_key;primaryKey"`
+	EpisodeID string `gorm:"column:episode_id;primaryKey"`
+}
+
+func (factSourceRow) TableName() string { return "memory_profile_fact_sources" }
+
+type sessionSummaryRow struct {
+	SessionID       string     `gorm:"column:session_id;primaryKey"`
+	TenantID        uint64     `gorm:"column:tenant_id"`
+	UserID          string     `gorm:"column:user_id"`
+	Summary         string     `gorm:"column:summary"`
+	SummarizedUntil *time.Time `gorm:"column:summarized_until"`
+	MessageCount    int        `gorm:"column:message_count"`
+	UpdatedAt       time.Time  `gorm:"column:updated_at"`
+}
+
+func (sessionSummaryRow) TableName() string { return "memory_session_summaries" }
+
+func encodeVector(vector []float32) string {
+	if len(vector) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(vector)
+	return string(data)
+}
+
+func decodeVector(data string) []float32 {
+	if data == "" {
+		return nil
+	}
+	var vector []float32
+	if err := json.Unmarshal([]byte(data), &vector); err != nil {
+		return nil
+	}
+	return vector
+}
@@ -132,6 +132,30 @@ func (r *messageRepository) GetFirstMessageOfUser(ctx context.Context, sessionID
 	return &message, nil
 }
 
+// GetUserMessageByRequestID retrieves the user message of a request
+func (r *messageRepository) GetUserMessageByRequestID(
+	ctx context.Context, sessionID string, requestID string,
+) (*types.Message, error) {
+	var message types.Message
+	if err := r.db.WithContext(ctx).Where(
+		"session_id = ? AND request_id = ? AND role = ?", sessionID, requestID, "user",
+	).First(&message).Error; err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// CountUserMessagesBySession counts the user messages of a session
+func (r *messageRepository) CountUserMessagesBySession(ctx context.Context, sessionID string) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&types.Message{}).Where(
+		"session_id = ? and role = ?", sessionID, "user",
+	).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 // GetMessageByRequestID retrieves a message by request ID
 func (r *messageRepository) GetMessageByRequestID(
 	ctx context.Context, sessionID string, requestID string,
@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// retentionRepository implements the RetentionRepository interface
+type retentionRepository struct {
+	db *gorm.DB
+}
+
+// NewRetentionRepository creates a new retention repository
+func NewRetentionRepository(db *gorm.DB) interfaces.RetentionRepository {
+	return &retentionRepository{db: db}
+}
+
+// GetPolicy returns the policy of a knowledge base or the tenant-wide policy, or nil
+func (r *retentionRepository) GetPolicy(
+	ctx context.Context, tenantID uint64, knowledgeBaseID string,
+) (*types.RetentionPolicy, error) {
+	var policy types.RetentionPolicy
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, knowledgeBaseID).
+		First(&policy).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// SavePolicy creates or replaces a policy
+func (r *retentionRepository) SavePolicy(ctx context.Context, policy *types.RetentionPolicy) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}, {Name: "knowledge_base_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"enabled", "session_retention_days", "memory_retention_days", "knowledge_retention_days",
+			"knowledge_expires_at", "next_run_at", "updated_at",
+		}),
+	}).Create(policy).Error
+}
+
+// ListPolicies returns the policies of a tenant, the tenant-wide one first
+func (r *retentionRepository) ListPolicies(ctx context.Context, tenantID uint64) ([]*types.RetentionPolicy, error) {
+	var policies []*types.RetentionPolicy
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("knowledge_base_id").
+		Find(&policies).Error
+	if err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// ListDuePolicies returns up to limit enabled policies due at now
+func (r *retentionRepository) ListDuePolicies(
+	ctx context.Context, now time.Time, limit int,
+) ([]*types.RetentionPolicy, error) {
+	var policies []*types.RetentionPolicy
+	err := r.db.WithContext(ctx).
+		Where("enabled = ? AND next_run_at IS NOT NULL AND next_run_at <= ?", true, now).
+		Order("next_run_at").
+		Limit(limit).
+		Find(&policies).Error
+	if err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// ClaimPolicy moves the next run of a policy from prev to next. The update
+// only matches while next_run_at still equals prev, so of several replicas
+// ticking at once exactly one wins. The winner also records the run.
+func (r *retentionRepository) ClaimPolicy(ctx context.Context, id string, prev, next time.Time) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&types.RetentionPolicy{}).
+		Where("id = ? AND enabled = ? AND next_run_at = ?", id, true, prev).
+		Updates(map[string]interface{}{"next_run_at": next, "last_run_at": now, "updated_at": now})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeletePolicy deletes a policy
+func (r *retentionRepository) DeletePolicy(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&types.RetentionPolicy{}).Error
+}
+
+// idleSessions selects the live sessions of a tenant not updated since before
+func (r *retentionRepository) idleSessions(ctx context.Context, tenantID uint64, before time.Time) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&types.Session{}).
+		Where("tenant_id = ? AND updated_at < ?", tenantID, before)
+}
+
+// CountIdleSessions counts the sessions of a tenant not updated since before
+func (r *retentionRepository) CountIdleSessions(
+	ctx context.Context, tenantID uint64, before time.Time,
+) (int64, error) {
+	var count int64
+	err := r.idleSessions(ctx, tenantID, before).Count(&count).Error
+	return count, err
+}
+
+// ListIdleSessionIDs returns up to limit sessions of a tenant not updated since before
+func (r *retentionRepository) ListIdleSessionIDs(
+	ctx context.Context, tenantID uint64, before time.Time, limit int,
+) ([]string, error) {
+	var ids []string
+	err := r.idleSessions(ctx, tenantID, before).
+		Order("updated_at").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// PurgeSessions permanently deletes sessions of a tenant with their messages.
+// Rows already soft-deleted go too: retention must not leave data behind.
+func (r *retentionRepository) PurgeSessions(ctx context.Context, tenantID uint64, ids []string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	var messages int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var owned []string
+		if err := tx.Unscoped().Model(&types.Session{}).
+			Where("tenant_id = ? AND id IN ?", tenantID, ids).
+			Pluck("id", &owned).Error; err != nil {
+			return err
+		}
+		if len(owned) == 0 {
+			return nil
+		}
+		result := tx.Unscoped().Where("session_id IN ?", owned).Delete(&types.Message{})
+		if result.Error != nil {
+			return result.Error
+		}
+		messages = result.RowsAffected
+		if err := purgeMessageSnapshots(tx, "session_id", owned); err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("tenant_id = ? AND id IN ?", tenantID, owned).Delete(&types.Session{}).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return messages, nil
+}
+
+// oldMessages selects the messages of a tenant created before before
+func (r *retentionRepository) oldMessages(tx *gorm.DB, tenantID uint64, before time.Time) *gorm.DB {
+	sessions := tx.Session(&gorm.Session{NewDB: true}).Unscoped().Model(&types.Session{}).
+		Select("id").Where("tenant_id = ?", tenantID)
+	return tx.Unscoped().Model(&types.Message{}).
+		Where("session_id IN (?) AND created_at < ?", sessions, before)
+}
+
+// CountOldMessages counts the messages of a tenant created before before
+func (r *retentionRepository) CountOldMessages(
+	ctx context.Context, tenantID uint64, before time.Time,
+) (int64, error) {
+	var count int64
+	err := r.oldMessages(r.db.WithContext(ctx), tenantID, before).Count(&count).Error
+	return count, err
+}
+
+// PurgeOldMessages permanently deletes up to limit messages of a tenant
+// created before before
+func (r *retentionRepository) PurgeOldMessages(
+	ctx context.Context, tenantID uint64, before time.Time, limit int,
+) (int64, error) {
+	var ids []string
+	db := r.db.WithContext(ctx)
+	if err := r.oldMessages(db, tenantID, before).Order("created_at").Limit(limit).Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	var deleted int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Where("id IN ?", ids).Delete(&types.Message{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		return purgeMessageSnapshots(tx, "message_id", ids)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// PurgeKnowledgeGaps permanently deletes the knowledge gaps of a tenant
+// last seen before before. Gaps snapshot questions too, but aggregate many
+// messages, so they go once the latest of them is past retention.
+func (r *retentionRepository) PurgeKnowledgeGaps(ctx context.Context, tenantID uint64, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("tenant_id = ? AND last_seen_at < ?", tenantID, before).
+		Delete(&types.KnowledgeGap{}).Error
+}
+
+// purgeMessageSnapshots deletes the feedback, shadow experiment samples and
+// query logs, which snapshot the question, of the messages or sessions whose
+// IDs are in values
+func purgeMessageSnapshots(tx *gorm.DB, column string, values []string) error {
+	if err := tx.Where(column+" IN ?", values).Delete(&types.ExperimentSample{}).Error; err != nil {
+		return err
+	}
+	queryLogs := tx.Session(&gorm.Session{NewDB: true}).Model(&types.QueryLog{}).
+		Select("id").Where(column+" IN ?", values)
+	if err := tx.Where("query_log_id IN (?)", queryLogs).Delete(&types.QueryLogKnowledgeBase{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("query_log_id IN (?)", queryLogs).Delete(&types.QueryLogDocument{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where(column+" IN ?", values).Delete(&types.QueryLog{}).Error; err != nil {
+		return err
+	}
+	feedback := tx.Session(&gorm.Session{NewDB: true}).Model(&types.MessageFeedback{}).
+		Select("id").Where(column+" IN ?", values)
+	if err := tx.Where("feedback_id IN (?)", feedback).Delete(&types.FeedbackSource{}).Error; err != nil {
+		return err
+	}
+	return tx.Where(column+" IN ?", values).Delete(&types.MessageFeedback{}).Error
+}
+
+// oldKnowledge selects the live documents of a knowledge base created before
+// before that are not already being deleted
+func (r *retentionRepository) oldKnowledge(
+	ctx context.Context, tenantID uint64, knowledgeBaseID string, before time.Time,
+) *gorm.DB {
+	return r.db.WithContext(ctx).Model(&types.Knowledge{}).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND created_at < ? AND parse_status <> ?",
+			tenantID, knowledgeBaseID, before, types.ParseStatusDeleting)
+}
+
+// CountOldKnowledge counts the documents of a knowledge base created before before
+func (r *retentionRepository) CountOldKnowledge(
+	ctx context.Context, tenantID uint64, knowledgeBaseID string, before time.Time,
+) (int64, error) {
+	var count int64
+	err := r.oldKnowledge(ctx, tenantID, knowledgeBaseID, before).Count(&count).Error
+	return count, err
+}
+
+// ListOldKnowledgeIDs returns up to limit documents of a knowledge base created before before
+func (r *retentionRepository) ListOldKnowledgeIDs(
+	ctx context.Context, tenantID uint64, knowledgeBaseID string, before time.Time, limit int,
+) ([]string, error) {
+	var ids []string
+	err := r.oldKnowledge(ctx, tenantID, knowledgeBaseID, before).
+		Order("created_at").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const retentionTestDDL = `
+CREATE TABLE sessions (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    created_at DATETIME,
+    updated_at DATETIME,
+    deleted_at DATETIME
+);
+CREATE TABLE messages (
+    id VARCHAR(36) PRIMARY KEY,
+    session_id VARCHAR(36) NOT NULL,
+    created_at DATETIME,
+    deleted_at DATETIME
+);
+CREATE TABLE knowledges (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    parse_status VARCHAR(50) NOT NULL DEFAULT 'completed',
+    created_at DATETIME,
+    deleted_at DATETIME
+);
+`
+
+func setupRetentionTestDB(t *testing.T) (*gorm.DB, *retentionRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(retentionTestDDL).Error)
+	require.NoError(t, db.AutoMigrate(
+		&types.RetentionPolicy{}, &types.MessageFeedback{}, &types.FeedbackSource{}, &types.ExperimentSample{},
+		&types.QueryLog{}, &types.QueryLogKnowledgeBase{}, &types.QueryLogDocument{}, &types.KnowledgeGap{},
+	))
+	return db, &retentionRepository{db: db}
+}
+
+func TestRetentionRepository_Policies(t *testing.T) {
+	_, repo := setupRetentionTestDB(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC().Truncate(time.Second)
+	due := now.Add(-time.Minute)
+	later := now.Add(time.Hour)
+	require.NoError(t, repo.SavePolicy(ctx, &types.RetentionPolicy{
+		ID: "p1", TenantID: 1, Enabled: true, SessionRetentionDays: 30, NextRunAt: &due,
+	}))
+	require.NoError(t, repo.SavePolicy(ctx, &types.RetentionPolicy{
+		ID: "p2", TenantID: 1, KnowledgeBaseID: "kb1", Enabled: true, KnowledgeRetentionDays: 90, NextRunAt: &later,
+	}))
+	// Saving the tenant-wide policy again replaces it in place.
+	require.NoError(t, repo.SavePolicy(ctx, &types.RetentionPolicy{
+		ID: "p3", TenantID: 1, Enabled: true, SessionRetentionDays: 7, MemoryRetentionDays: 14, NextRunAt: &due,
+	}))
+
+	policy, err := repo.GetPolicy(ctx, 1, "")
+	require.NoError(t, err)
+	require.NotNil(t, policy)
+	assert.Equal(t, "p1", policy.ID)
+	assert.Equal(t, 7, policy.SessionRetentionDays)
+	assert.Equal(t, 14, policy.MemoryRetentionDays)
+
+	missing, err := repo.GetPolicy(ctx, 2, "")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	policies, err := repo.ListPolicies(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, policies, 2)
+	assert.True(t, policies[0].IsTenantWide(), "tenant-wide policy first")
+
+	dueList, err := repo.ListDuePolicies(ctx, now, 10)
+	require.NoError(t, err)
+	require.Len(t, dueList, 1)
+	assert.Equal(t, "p1", dueList[0].ID)
+
+	won, err := repo.ClaimPolicy(ctx, "p1", *dueList[0].NextRunAt, now.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.True(t, won)
+	won, err = repo.ClaimPolicy(ctx, "p1", *dueList[0].NextRunAt, now.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.False(t, won, "a policy is claimed once per run")
+	policy, err = repo.GetPolicy(ctx, 1, "")
+	require.NoError(t, err)
+	assert.NotNil(t, policy.LastRunAt)
+
+	require.NoError(t, repo.DeletePolicy(ctx, "p2"))
+	policy, err = repo.GetPolicy(ctx, 1, "kb1")
+	require.NoError(t, err)
+	assert.Nil(t, policy)
+}
+
+func TestRetentionRepository_SessionsAndMessages(t *testing.T) {
+	db, repo := setupRetentionTestDB(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	old := now.AddDate(0, 0, -40)
+	cutoff := now.AddDate(0, 0, -30)
+	for _, s := range []struct {
+		id       string
+		tenantID uint64
+		updated  time.Time
+	}{
+		{"idle", 1, old},
+		{"active", 1, now},
+		{"other-tenant", 2, old},
+	} {
+		require.NoError(t, db.Exec("INSERT INTO sessions (id, tenant_id, created_at, updated_at) VALUES (?, ?, ?, ?)",
+			s.id, s.tenantID, old, s.updated).Error)
+	}
+	for _, m := range []struct {
+		id, sessionID string
+		created       time.Time
+	}{
+		{"m1", "idle", old},
+		{"m2", "idle", old},
+		{"m3", "active", old},
+		{"m4", "active", now},
+		{"m5", "other-tenant", old},
+	} {
+		require.NoError(t, db.Exec("INSERT INTO messages (id, session_id, created_at) VALUES (?, ?, ?)",
+			m.id, m.sessionID, m.created).Error)
+	}
+
+	for _, f := range []struct{ id, sessionID, messageID string }{
+		{"f1", "idle", "m1"},
+		{"f3", "active", "m3"},
+		{"f4", "active", "m4"},
+	} {
+		require.NoError(t, db.Create(&types.MessageFeedback{
+			ID: f.id, TenantID: 1, SessionID: f.sessionID, MessageID: f.messageID, Rating: types.FeedbackRatingUp,
+		}).Error)
+		require.NoError(t, db.Create(&types.FeedbackSource{FeedbackID: f.id, TenantID: 1, ChunkID: "c1"}).Error)
+		require.NoError(t, db.Create(&types.ExperimentSample{
+			ID: "x" + f.id, ExperimentID: "e1", TenantID: 1, SessionID: f.sessionID, MessageID: f.messageID,
+		}).Error)
+		require.NoError(t, db.Create(&types.QueryLog{
+			ID: "q" + f.id, TenantID: 1, SessionID: f.sessionID, MessageID: f.messageID,
+		}).Error)
+		require.NoError(t, db.Create(&types.QueryLogKnowledgeBase{QueryLogID: "q" + f.id, KnowledgeBaseID: "kb1"}).Error)
+		require.NoError(t, db.Create(&types.QueryLogDocument{QueryLogID: "q" + f.id, KnowledgeID: "k1"}).Error)
+	}
+
+	count, err := repo.CountIdleSessions(ctx, 1, cutoff)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	ids, err := repo.ListIdleSessionIDs(ctx, 1, cutoff, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"idle"}, ids)
+
+	count, err = repo.CountOldMessages(ctx, 1, cutoff)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	messages, err := repo.PurgeSessions(ctx, 1, []string{"idle", "other-tenant"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), messages, "sessions of other tenants are left alone")
+
+	deleted, err := repo.PurgeOldMessages(ctx, 1, cutoff, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	var remaining []string
+	require.NoError(t, db.Raw("SELECT id FROM messages ORDER BY id").Scan(&remaining).Error)
+	assert.Equal(t, []string{"m4", "m5"}, remaining)
+	require.NoError(t, db.Raw("SELECT id FROM sessions ORDER BY id").Scan(&remaining).Error)
+	assert.Equal(t, []string{"active", "other-tenant"}, remaining)
+	require.NoError(t, db.Raw("SELECT id FROM message_feedbacks ORDER BY id").Scan(&remaining).Error)
+	assert.Equal(t, []string{"f4"}, remaining, "feedback snapshots go with their messages")
+	require.NoError(t, db.Raw("SELECT feedback_id FROM message_feedback_sources").Scan(&remaining).Error)
+	assert.Equal(t, []string{"f4"}, remaining)
+	require.NoError(t, db.Raw("SELECT id FROM experiment_samples").Scan(&remaining).Error)
+	assert.Equal(t, []string{"xf4"}, remaining, "shadow answers go with their messages too")
+	require.NoError(t, db.Raw("SELECT id FROM query_logs").Scan(&remaining).Error)
+	assert.Equal(t, []string{"qf4"}, remaining, "so do the logged queries")
+	require.NoError(t, db.Raw("SELECT query_log_id FROM query_log_knowledge_bases").Scan(&remaining).Error)
+	assert.Equal(t, []string{"qf4"}, remaining)
+	require.NoError(t, db.Raw("SELECT query_log_id FROM query_log_documents").Scan(&remaining).Error)
+	assert.Equal(t, []string{"qf4"}, remaining)
+}
+
+func TestRetentionRepository_Knowledge(t *testing.T) {
+	db, repo := setupRetentionTestDB(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	old := now.AddDate(0, 0, -100)
+	cutoff := now.AddDate(0, 0, -90)
+	for _, k := range []struct {
+		id, kbID, status string
+		created          time.Time
+	}{
+		{"k1", "kb1", types.ParseStatusCompleted, old},
+		{"k2", "kb1", types.ParseStatusDeleting, old},
+		{"k3", "kb1", types.ParseStatusCompleted, now},
+		{"k4", "kb2", types.ParseStatusCompleted, old},
+	} {
+		require.NoError(t, db.Exec(
+			"INSERT INTO knowledges (id, tenant_id, knowledge_base_id, parse_status, created_at) VALUES (?, 1, ?, ?, ?)",
+			k.id, k.kbID, k.status, k.created).Error)
+	}
+
+	count, err := repo.CountOldKnowledge(ctx, 1, "kb1", cutoff)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	ids, err := repo.ListOldKnowledgeIDs(ctx, 1, "kb1", cutoff, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"k1"}, ids, "documents being deleted and of other knowledge bases are skipped")
+}
+
+func TestRetentionRepository_PurgeKnowledgeGaps(t *testing.T) {
+	db, repo := setupRetentionTestDB(t)
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	cutoff := now.AddDate(0, 0, -30)
+	for _, g := range []struct {
+		id       string
+		tenantID uint64
+		lastSeen time.Time
+	}{
+		{"g1", 1, now.AddDate(0, 0, -40)},
+		{"g2", 1, now},
+		{"g3", 2, now.AddDate(0, 0, -40)},
+	} {
+		require.NoError(t, db.Create(&types.KnowledgeGap{
+			ID: g.id, TenantID: g.tenantID, KnowledgeBaseID: "kb1", QueryHash: g.id, Query: g.id,
+			Count: 1, FirstSeenAt: g.lastSeen, LastSeenAt: g.lastSeen,
+		}).Error)
+	}
+
+	require.NoError(t, repo.PurgeKnowledgeGaps(ctx, 1, cutoff))
+	var left []string
+	require.NoError(t, db.Model(&types.KnowledgeGap{}).Order("id").Pluck("id", &left).Error)
+	assert.Equal(t, []string{"g2", "g3"}, left)
+}
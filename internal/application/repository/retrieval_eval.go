@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// retrievalEvalRepository implements the RetrievalEvalRepository interface
+type retrievalEvalRepository struct {
+	db *gorm.DB
+}
+
+// NewRetrievalEvalRepository creates a new retrieval evaluation repository
+func NewRetrievalEvalRepository(db *gorm.DB) interfaces.RetrievalEvalRepository {
+	return &retrievalEvalRepository{db: db}
+}
+
+// CreateDataset creates a dataset with its items
+func (r *retrievalEvalRepository) CreateDataset(
+	ctx context.Context, dataset *types.EvalDataset, items []*types.EvalDatasetItem,
+) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(dataset).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(items, 100).Error
+	})
+}
+
+// GetDataset returns a dataset of a tenant, or nil
+func (r *retrievalEvalRepository) GetDataset(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.EvalDataset, error) {
+	var dataset types.EvalDataset
+	err := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).First(&dataset).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &dataset, nil
+}
+
+// ListDatasets returns the datasets of a tenant, newest first
+func (r *retrievalEvalRepository) ListDatasets(ctx context.Context, tenantID uint64) ([]*types.EvalDataset, error) {
+	var datasets []*types.EvalDataset
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ?", tenantID).
+		Order("created_at DESC").
+		Find(&datasets).Error
+	if err != nil {
+		return nil, err
+	}
+	return datasets, nil
+}
+
+// ListDatasetItems returns the items of a dataset in upload order
+func (r *retrievalEvalRepository) ListDatasetItems(
+	ctx context.Context, datasetID string,
+) ([]*types.EvalDatasetItem, error) {
+	var items []*types.EvalDatasetItem
+	err := r.db.WithContext(ctx).
+		Where("dataset_id = ?", datasetID).
+		Order("position").
+		Find(&items).Error
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// DeleteDataset deletes a dataset of a tenant with its items, runs and results
+func (r *retrievalEvalRepository) DeleteDataset(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("tenant_id = ? AND id = ?", tenantID, id).Delete(&types.EvalDataset{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		runs := tx.Session(&gorm.Session{NewDB: true}).Model(&types.EvalRun{}).
+			Select("id").Where("dataset_id = ?", id)
+		if err := tx.Where("run_id IN (?)", runs).Delete(&types.EvalRunResult{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("dataset_id = ?", id).Delete(&types.EvalRun{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("dataset_id = ?", id).Delete(&types.EvalDatasetItem{}).Error
+	})
+}
+
+// CreateRun creates a run
+func (r *retrievalEvalRepository) CreateRun(ctx context.Context, run *types.EvalRun) error {
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+// GetRun returns a run of a tenant, or nil
+func (r *retrievalEvalRepository) GetRun(ctx context.Context, tenantID uint64, id string) (*types.EvalRun, error) {
+	var run types.EvalRun
+	err := r.db.WithContext(ctx).Where("tenant_id = ? AND id = ?", tenantID, id).First(&run).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// ListRuns returns the runs of a tenant, of one dataset when datasetID is set, newest first
+func (r *retrievalEvalRepository) ListRuns(
+	ctx context.Context, tenantID uint64, datasetID string,
+) ([]*types.EvalRun, error) {
+	query := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID)
+	if datasetID != "" {
+		query = query.Where("dataset_id = ?", datasetID)
+	}
+	var runs []*types.EvalRun
+	if err := query.Order("created_at DESC").Find(&runs).Error; err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// UpdateRun saves the state, progress and metrics of a run
+func (r *retrievalEvalRepository) UpdateRun(ctx context.Context, run *types.EvalRun) error {
+	return r.db.WithContext(ctx).Model(run).
+		Select("status", "config", "metrics", "total", "finished", "failed", "err_msg",
+			"started_at", "finished_at", "updated_at").
+		Updates(run).Error
+}
+
+// DeleteRun deletes a run of a tenant with its results
+func (r *retrievalEvalRepository) DeleteRun(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("tenant_id = ? AND id = ?", tenantID, id).Delete(&types.EvalRun{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		return tx.Where("run_id = ?", id).Delete(&types.EvalRunResult{}).Error
+	})
+}
+
+// SaveRunResult stores the result of one question
+func (r *retrievalEvalRepository) SaveRunResult(ctx context.Context, result *types.EvalRunResult) error {
+	return r.db.WithContext(ctx).Create(result).Error
+}
+
+// DeleteRunResults deletes the results of a run
+func (r *retrievalEvalRepository) DeleteRunResults(ctx context.Context, runID string) error {
+	return r.db.WithContext(ctx).Where("run_id = ?", runID).Delete(&types.EvalRunResult{}).Error
+}
+
+// ListRunResults returns the results of a run in dataset order
+func (r *retrievalEvalRepository) ListRunResults(ctx context.Context, runID string) ([]*types.EvalRunResult, error) {
+	var results []*types.EvalRunResult
+	err := r.db.WithContext(ctx).
+		Where("run_id = ?", runID).
+		Order("position").
+		Find(&results).Error
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupRetrievalEvalTestDB(t *testing.T) (*gorm.DB, *retrievalEvalRepository) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(
+		&types.EvalDataset{}, &types.EvalDatasetItem{}, &types.EvalRun{}, &types.EvalRunResult{},
+	))
+	return db, &retrievalEvalRepository{db: db}
+}
+
+func TestRetrievalEvalRepository_Datasets(t *testing.T) {
+	_, repo := setupRetrievalEvalTestDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, repo.CreateDataset(ctx, &types.EvalDataset{ID: "d1", TenantID: 1, Name: "golden", ItemCount: 2},
+		[]*types.EvalDatasetItem{
+			{ID: "i2", DatasetID: "d1", Position: 1, Question: "q2", ExpectedAnswer: "a2"},
+			{ID: "i1", DatasetID: "d1", Position: 0, Question: "q1",
+				ExpectedSources: types.EvalExpectedSources{{Title: "Handbook"}, {ChunkID: "c1"}}},
+		}))
+
+	dataset, err := repo.GetDataset(ctx, 1, "d1")
+	require.NoError(t, err)
+	require.NotNil(t, dataset)
+	assert.Equal(t, "golden", dataset.Name)
+
+	dataset, err = repo.GetDataset(ctx, 2, "d1")
+	require.NoError(t, err)
+	assert.Nil(t, dataset, "datasets are scoped to their tenant")
+
+	items, err := repo.ListDatasetItems(ctx, "d1")
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, "i1", items[0].ID)
+	assert.Equal(t, types.EvalExpectedSources{{Title: "Handbook"}, {ChunkID: "c1"}}, items[0].ExpectedSources)
+	assert.Empty(t, items[1].ExpectedSources)
+
+	datasets, err := repo.ListDatasets(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, datasets, 1)
+}
+
+func TestRetrievalEvalRepository_Runs(t *testing.T) {
+	db, repo := setupRetrievalEvalTestDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, repo.CreateDataset(ctx, &types.EvalDataset{ID: "d1", TenantID: 1, Name: "golden", ItemCount: 1},
+		[]*types.EvalDatasetItem{{ID: "i1", DatasetID: "d1", Question: "q1", ExpectedAnswer: "a1"}}))
+
+	threshold := 0.3
+	run := &types.EvalRun{
+		ID: "r1", TenantID: 1, DatasetID: "d1", KnowledgeBaseID: "kb1", Status: types.EvalRunStatusPending,
+		Config: types.EvalRunConfig{Mode: types.EvalRunModeRAG, TopK: 5, VectorThreshold: &threshold},
+	}
+	require.NoError(t, repo.CreateRun(ctx, run))
+	require.NoError(t, repo.CreateRun(ctx, &types.EvalRun{ID: "r2", TenantID: 1, DatasetID: "d2"}))
+
+	got, err := repo.GetRun(ctx, 1, "r1")
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, run.Config, got.Config)
+	assert.Nil(t, got.Metrics)
+
+	faithfulness := 0.9
+	got.Status = types.EvalRunStatusCompleted
+	got.Finished = 1
+	got.Metrics = &types.EvalMetrics{RecallAtK: 0.5, Faithfulness: &faithfulness}
+	require.NoError(t, repo.UpdateRun(ctx, got))
+	got, err = repo.GetRun(ctx, 1, "r1")
+	require.NoError(t, err)
+	assert.Equal(t, types.EvalRunStatusCompleted, got.Status)
+	assert.Equal(t, 1, got.Finished)
+	require.NotNil(t, got.Metrics)
+	assert.Equal(t, 0.5, got.Metrics.RecallAtK)
+
+	runs, err := repo.ListRuns(ctx, 1, "d1")
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	runs, err = repo.ListRuns(ctx, 1, "")
+	require.NoError(t, err)
+	assert.Len(t, runs, 2)
+
+	require.NoError(t, repo.SaveRunResult(ctx, &types.EvalRunResult{ID: "res2", RunID: "r1", Position: 1,
+		Retrieved: types.EvalRetrievedSources{{ChunkID: "c1", Expected: -1}}}))
+	require.NoError(t, repo.SaveRunResult(ctx, &types.EvalRunResult{ID: "res1", RunID: "r1", Position: 0}))
+	results, err := repo.ListRunResults(ctx, "r1")
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "res1", results[0].ID)
+	assert.Equal(t, types.EvalRetrievedSources{{ChunkID: "c1", Expected: -1}}, results[1].Retrieved)
+
+	require.NoError(t, repo.DeleteRunResults(ctx, "r1"))
+	results, err = repo.ListRunResults(ctx, "r1")
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	require.NoError(t, repo.SaveRunResult(ctx, &types.EvalRunResult{ID: "res3", RunID: "r2"}))
+	require.NoError(t, repo.DeleteRun(ctx, 2, "r2"), "another tenant's run is left alone")
+	var count int64
+	db.Model(&types.EvalRunResult{}).Where("run_id = ?", "r2").Count(&count)
+	assert.Equal(t, int64(1), count)
+	require.NoError(t, repo.DeleteRun(ctx, 1, "r2"))
+	db.Model(&types.EvalRunResult{}).Where("run_id = ?", "r2").Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestRetrievalEvalRepository_DeleteDataset(t *testing.T) {
+	db, repo := setupRetrievalEvalTestDB(t)
+	ctx := context.Background()
+
+	require.NoError(t, repo.CreateDataset(ctx, &types.EvalDataset{ID: "d1", TenantID: 1, Name: "golden"},
+		[]*types.EvalDatasetItem{{ID: "i1", DatasetID: "d1", Question: "q1"}}))
+	require.NoError(t, repo.CreateRun(ctx, &types.EvalRun{ID: "r1", TenantID: 1, DatasetID: "d1"}))
+	require.NoError(t, repo.SaveRunResult(ctx, &types.EvalRunResult{ID: "res1", RunID: "r1"}))
+
+	require.NoError(t, repo.DeleteDataset(ctx, 2, "d1"))
+	dataset, err := repo.GetDataset(ctx, 1, "d1")
+	require.NoError(t, err)
+	assert.NotNil(t, dataset, "another tenant cannot delete the dataset")
+
+	require.NoError(t, repo.DeleteDataset(ctx, 1, "d1"))
+	for _, model := range []interface{}{
+		&types.EvalDataset{}, &types.EvalDatasetItem{}, &types.EvalRun{}, &types.EvalRunResult{},
+	} {
+		var count int64
+		require.NoError(t, db.Model(model).Count(&count).Error)
+		assert.Zero(t, count)
+	}
+}
@@ -134,11 +134,11 @@ func buildBaseFilter(params types.RetrieveParams) *whereBuilder {
 	if len(params.TagIDs) > 0 {
 		w.addIn(fieldTagID, params.TagIDs)
 	}
-	if len(params.ExcludeKnowledgeIDs) > 0 {
-		w.addNotIn(fieldKnowledgeID, params.ExcludeKnowledgeIDs)
+	if excluded := params.ExcludedKnowledgeIDs(); len(excluded) > 0 {
+		w.addNotIn(fieldKnowledgeID, excluded)
 	}
-	if len(params.ExcludeChunkIDs) > 0 {
-		w.addNotIn(fieldChunkID, params.ExcludeChunkIDs)
+	if excludedChunks := params.ExcludedChunkIDs(); len(excludedChunks) > 0 {
+		w.addNotIn(fieldChunkID, excludedChunks)
 	}
 	return w
 }
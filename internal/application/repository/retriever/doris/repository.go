@@ -69,6 +69,15 @@ func (r *dorisRepository) Support() []types.RetrieverType {
 	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
 }
 
+// Capabilities reports Doris features. Only the legacy table layout
+// updates fields through Stream Load partial columns; the default layout
+// rewrites whole rows.
+func (r *dorisRepository) Capabilities() types.EngineCapabilities {
+	caps := types.NewEngineCapabilities(r.EngineType(), r.Support())
+	caps.PartialUpdate = r.compatModeRequested == dorisCompatModeLegacy
+	return caps
+}
+
 // EstimateStorageSize 估算给定 IndexInfo 列表的存储字节数。
 //
 // 参考 Qdrant 的算法：payload 字段长度 + 向量字节 + HNSW 邻居 + 元数据。
@@ -253,6 +262,55 @@ func (r *dorisRepository) DeleteBySourceIDList(ctx context.Context,
 	return r.deleteByField(ctx, fieldSourceID, sourceIDList, dimension)
 }
 
+// DeleteByKnowledgeBaseIDList 用 knowledge_base_id 列删除，删除后若该维度的
+// 表已为空则一并删除该表。
+func (r *dorisRepository) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, _ string,
+) error {
+	if err := r.deleteByField(ctx, fieldKnowledgeBaseID, knowledgeBaseIDList, dimension); err != nil {
+		return err
+	}
+	r.dropTableIfEmpty(ctx, dimension, len(knowledgeBaseIDList))
+	return nil
+}
+
+// DeleteByTagIDList 用 tag_id 列删除，删除后若该维度的表已为空则一并删除该表。
+func (r *dorisRepository) DeleteByTagIDList(ctx context.Context,
+	tagIDList []string, dimension int, _ string,
+) error {
+	if err := r.deleteByField(ctx, fieldTagID, tagIDList, dimension); err != nil {
+		return err
+	}
+	r.dropTableIfEmpty(ctx, dimension, len(tagIDList))
+	return nil
+}
+
+// dropTableIfEmpty 在整库/整标签删除后清理空的按维度分表，避免残留空表。
+// 删除已成功，清理失败只记录日志；表会在下次写入时由 ensureTable 重建。
+// deleted 为 0（空 ID 列表，未执行删除）时直接跳过。
+func (r *dorisRepository) dropTableIfEmpty(ctx context.Context, dimension, deleted int) {
+	if deleted == 0 {
+		return
+	}
+	log := logger.GetLogger(ctx)
+	table := r.getTableName(dimension)
+
+	var remaining int64
+	if err := r.db.QueryRowContext(ctx, fmt.Sprintf("SELECT COUNT(1) FROM `%s`", table)).Scan(&remaining); err != nil {
+		log.Warnf("[Doris] Skipping empty table cleanup of %s: %v", table, err)
+		return
+	}
+	if remaining > 0 {
+		return
+	}
+	if _, err := r.db.ExecContext(ctx, fmt.Sprintf("DROP TABLE IF EXISTS `%s`", table)); err != nil {
+		log.Warnf("[Doris] Failed to drop empty table %s: %v", table, err)
+		return
+	}
+	r.initializedTables.Delete(dimension)
+	log.Infof("[Doris] Dropped empty table %s", table)
+}
+
 // deleteByField 是三个 Delete* 方法的统一实现：
 // DELETE FROM <table> WHERE <field> IN (?, ?, ...)。
 func (r *dorisRepository) deleteByField(ctx context.Context,
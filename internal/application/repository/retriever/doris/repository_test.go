@@ -131,6 +131,17 @@ func TestWhereBuilder(t *testing.T) {
 		assert.Contains(t, clause, "knowledge_id NOT IN (?)")
 		assert.Contains(t, clause, "chunk_id NOT IN (?)")
 	})
+
+	t.Run("buildBaseFilter drops staged chunks", func(t *testing.T) {
+		w := buildBaseFilter(types.RetrieveParams{
+			KnowledgeBaseIDs: []string{"kb1"},
+			ExcludeChunkIDs:  []string{"c9"},
+			StagedChunkIDs:   []string{"s1", "s2"},
+		})
+		clause, args := w.build()
+		assert.Contains(t, clause, "chunk_id NOT IN (?, ?, ?)")
+		assert.Subset(t, args, []any{"c9", "s1", "s2"})
+	})
 }
 
 // ---------------------------------------------------------------------------
@@ -257,6 +268,39 @@ func TestDeleteByKnowledgeIDList_NoOpOnEmpty(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestDeleteByKnowledgeBaseIDList_DropsEmptyTable(t *testing.T) {
+	repo, mock, _, cleanup := newTestRepo(t)
+	defer cleanup()
+	repo.initializedTables.Store(768, true)
+
+	mock.ExpectExec(`DELETE FROM .*weknora_embeddings_768.* WHERE knowledge_base_id IN \(\?\)`).
+		WithArgs("kb1").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery(`SELECT COUNT\(1\) FROM .*weknora_embeddings_768`).
+		WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(0))
+	mock.ExpectExec(`DROP TABLE IF EXISTS .*weknora_embeddings_768`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	require.NoError(t, repo.DeleteByKnowledgeBaseIDList(context.Background(), []string{"kb1"}, 768, ""))
+	assert.NoError(t, mock.ExpectationsWereMet())
+	_, cached := repo.initializedTables.Load(768)
+	assert.False(t, cached, "dropped table must be recreated on next write")
+}
+
+func TestDeleteByTagIDList_KeepsNonEmptyTable(t *testing.T) {
+	repo, mock, _, cleanup := newTestRepo(t)
+	defer cleanup()
+
+	mock.ExpectExec(`DELETE FROM .*weknora_embeddings_768.* WHERE tag_id IN \(\?, \?\)`).
+		WithArgs("t1", "t2").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery(`SELECT COUNT\(1\) FROM .*weknora_embeddings_768`).
+		WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow(5))
+
+	require.NoError(t, repo.DeleteByTagIDList(context.Background(), []string{"t1", "t2"}, 768, ""))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestVectorRetrieve_SQLShape(t *testing.T) {
 	repo, mock, _, cleanup := newTestRepo(t)
 	defer cleanup()
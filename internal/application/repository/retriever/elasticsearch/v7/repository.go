@@ -191,6 +191,16 @@ func (e *elasticsearchRepository) Support() []typesLocal.RetrieverType {
 	return []typesLocal.RetrieverType{typesLocal.KeywordsRetrieverType}
 }
 
+// Capabilities reports Elasticsearch features: field updates run as
+// update_by_query scripts, and results are bounded by the default
+// index.max_result_window.
+func (e *elasticsearchRepository) Capabilities() typesLocal.EngineCapabilities {
+	caps := typesLocal.NewEngineCapabilities(e.EngineType(), e.Support())
+	caps.PartialUpdate = true
+	caps.MaxTopK = 10000
+	return caps
+}
+
 // EstimateStorageSize 估算存储空间大小
 func (e *elasticsearchRepository) EstimateStorageSize(ctx context.Context,
 	indexInfoList []*typesLocal.IndexInfo, params map[string]any,
@@ -256,17 +266,18 @@ func (e *elasticsearchRepository) Save(ctx context.Context,
 		return err
 	}
 
-	docID := uuid.New().String()
-	log.Debugf("[ElasticsearchV7] Creating document with ID: %s for chunk ID: %s", docID, embedding.ChunkID)
+	// 使用确定性 ID 并以 index 语义写入，重试时覆盖旧文档而不是产生重复
+	docID := embedding.RowID()
+	log.Debugf("[ElasticsearchV7] Indexing document with ID: %s for chunk ID: %s", docID, embedding.ChunkID)
 
-	resp, err := e.client.Create(
+	resp, err := e.client.Index(
 		e.index,
-		docID,
 		bytes.NewReader(docBytes),
-		e.client.Create.WithContext(ctx),
+		e.client.Index.WithDocumentID(docID),
+		e.client.Index.WithContext(ctx),
 	)
 	if err != nil {
-		log.Errorf("[ElasticsearchV7] Failed to create document: %v", err)
+		log.Errorf("[ElasticsearchV7] Failed to index document: %v", err)
 		return err
 	}
 	defer resp.Body.Close()
@@ -344,8 +355,8 @@ func (e *elasticsearchRepository) prepareBulkRequestBody(ctx context.Context,
 		// Convert to Elasticsearch document format
 		embeddingDB := elasticsearchRetriever.ToDBVectorEmbedding(embedding, additionalParams)
 
-		// Generate document ID and metadata line
-		docID := uuid.New().String()
+		// Deterministic document ID keeps retried bulk requests idempotent
+		docID := embedding.RowID()
 		meta := []byte(fmt.Sprintf(`{ "index" : { "_id" : "%s" } }%s`, docID, "\n"))
 
 		// Marshal document to JSON
@@ -439,6 +450,20 @@ func (e *elasticsearchRepository) DeleteByKnowledgeIDList(ctx context.Context,
 	return e.deleteByFieldList(ctx, e.idField("knowledge_id"), knowledgeIDList)
 }
 
+// DeleteByKnowledgeBaseIDList Delete every index of the given knowledge bases
+func (e *elasticsearchRepository) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	return e.deleteByFieldList(ctx, e.idField("knowledge_base_id"), knowledgeBaseIDList)
+}
+
+// DeleteByTagIDList Delete indices by tag ID list
+func (e *elasticsearchRepository) DeleteByTagIDList(ctx context.Context,
+	tagIDList []string, dimension int, knowledgeType string,
+) error {
+	return e.deleteByFieldList(ctx, e.idField("tag_id"), tagIDList)
+}
+
 // deleteByFieldList Delete documents by field value list
 func (e *elasticsearchRepository) deleteByFieldList(ctx context.Context, field string, valueList []string) error {
 	log := logger.GetLogger(ctx)
@@ -533,17 +558,17 @@ func (e *elasticsearchRepository) getBaseConds(params typesLocal.RetrieveParams)
 			"is_enabled": false,
 		},
 	})
-	if len(params.ExcludeKnowledgeIDs) > 0 {
+	if excluded := params.ExcludedKnowledgeIDs(); len(excluded) > 0 {
 		mustNot = append(mustNot, map[string]interface{}{
 			"terms": map[string]interface{}{
-				e.idField("knowledge_id"): params.ExcludeKnowledgeIDs,
+				e.idField("knowledge_id"): excluded,
 			},
 		})
 	}
-	if len(params.ExcludeChunkIDs) > 0 {
+	if excludedChunks := params.ExcludedChunkIDs(); len(excludedChunks) > 0 {
 		mustNot = append(mustNot, map[string]interface{}{
 			"terms": map[string]interface{}{
-				e.idField("chunk_id"): params.ExcludeChunkIDs,
+				e.idField("chunk_id"): excludedChunks,
 			},
 		})
 	}
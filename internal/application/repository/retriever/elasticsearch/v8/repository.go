@@ -113,6 +113,16 @@ func (e *elasticsearchRepository) Support() []typesLocal.RetrieverType {
 	return []typesLocal.RetrieverType{typesLocal.KeywordsRetrieverType, typesLocal.VectorRetrieverType}
 }
 
+// Capabilities reports Elasticsearch features: field updates run as
+// update_by_query scripts, and results are bounded by the default
+// index.max_result_window.
+func (e *elasticsearchRepository) Capabilities() typesLocal.EngineCapabilities {
+	caps := typesLocal.NewEngineCapabilities(e.EngineType(), e.Support())
+	caps.PartialUpdate = true
+	caps.MaxTopK = 10000
+	return caps
+}
+
 // calculateStorageSize estimates the storage size in bytes for a single index document
 func (e *elasticsearchRepository) calculateStorageSize(embedding *elasticsearchRetriever.VectorEmbedding) int64 {
 	// 1. Content text size
@@ -169,8 +179,9 @@ func (e *elasticsearchRepository) Save(ctx context.Context,
 		return err
 	}
 
-	// Index the document
-	resp, err := e.client.Index(e.index).Request(embeddingDB).Do(ctx)
+	// Index under a deterministic ID so a retried Save overwrites the
+	// earlier document instead of duplicating it.
+	resp, err := e.client.Index(e.index).Id(embedding.RowID()).Request(embeddingDB).Do(ctx)
 	if err != nil {
 		log.Errorf("[Elasticsearch] Failed to save index: %v", err)
 		return err
@@ -197,7 +208,8 @@ func (e *elasticsearchRepository) BatchSave(ctx context.Context,
 	// Add each document to the bulk request
 	for _, embedding := range embeddingList {
 		embeddingDB := elasticsearchRetriever.ToDBVectorEmbedding(embedding, additionalParams)
-		err := indexRequest.CreateOp(types.CreateOperation{Index_: &e.index}, embeddingDB)
+		docID := embedding.RowID()
+		err := indexRequest.IndexOp(types.IndexOperation{Index_: &e.index, Id_: &docID}, embeddingDB)
 		if err != nil {
 			log.Errorf("[Elasticsearch] Failed to create bulk operation: %v", err)
 			return fmt.Errorf("failed to create op: %w", err)
@@ -287,6 +299,54 @@ func (e *elasticsearchRepository) DeleteByKnowledgeIDList(ctx context.Context,
 	return nil
 }
 
+// DeleteByKnowledgeBaseIDList removes every document of the given knowledge bases
+// Returns an error if the delete operation fails
+func (e *elasticsearchRepository) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(knowledgeBaseIDList) == 0 {
+		log.Warn("[Elasticsearch] Empty knowledge base ID list provided for deletion, skipping")
+		return nil
+	}
+
+	log.Infof("[Elasticsearch] Deleting indices by knowledge base IDs, count: %d", len(knowledgeBaseIDList))
+	_, err := e.client.DeleteByQuery(e.index).Query(&types.Query{
+		Terms: &types.TermsQuery{TermsQuery: map[string]types.TermsQueryField{e.idField("knowledge_base_id"): knowledgeBaseIDList}},
+	}).Do(ctx)
+	if err != nil {
+		log.Errorf("[Elasticsearch] Failed to delete by knowledge base IDs: %v", err)
+		return fmt.Errorf("failed to delete by query: %w", err)
+	}
+
+	log.Infof("[Elasticsearch] Successfully deleted documents by knowledge base IDs")
+	return nil
+}
+
+// DeleteByTagIDList removes documents from the index based on tag IDs
+// Returns an error if the delete operation fails
+func (e *elasticsearchRepository) DeleteByTagIDList(ctx context.Context,
+	tagIDList []string, dimension int, knowledgeType string,
+) error {
+	log := logger.GetLogger(ctx)
+	if len(tagIDList) == 0 {
+		log.Warn("[Elasticsearch] Empty tag ID list provided for deletion, skipping")
+		return nil
+	}
+
+	log.Infof("[Elasticsearch] Deleting indices by tag IDs, count: %d", len(tagIDList))
+	_, err := e.client.DeleteByQuery(e.index).Query(&types.Query{
+		Terms: &types.TermsQuery{TermsQuery: map[string]types.TermsQueryField{e.idField("tag_id"): tagIDList}},
+	}).Do(ctx)
+	if err != nil {
+		log.Errorf("[Elasticsearch] Failed to delete by tag IDs: %v", err)
+		return fmt.Errorf("failed to delete by query: %w", err)
+	}
+
+	log.Infof("[Elasticsearch] Successfully deleted documents by tag IDs")
+	return nil
+}
+
 // getBaseConds creates the base query conditions for retrieval operations
 // Returns a slice of Query objects with must and must_not conditions
 // KnowledgeBaseIDs and KnowledgeIDs use AND logic (search specific documents within knowledge bases)
@@ -326,14 +386,14 @@ func (e *elasticsearchRepository) getBaseConds(params typesLocal.RetrieveParams)
 	mustNot = append(mustNot, types.Query{Term: map[string]types.TermQuery{
 		"is_enabled": {Value: false},
 	}})
-	if len(params.ExcludeKnowledgeIDs) > 0 {
+	if excluded := params.ExcludedKnowledgeIDs(); len(excluded) > 0 {
 		mustNot = append(mustNot, types.Query{Terms: &types.TermsQuery{
-			TermsQuery: map[string]types.TermsQueryField{e.idField("knowledge_id"): params.ExcludeKnowledgeIDs},
+			TermsQuery: map[string]types.TermsQueryField{e.idField("knowledge_id"): excluded},
 		}})
 	}
-	if len(params.ExcludeChunkIDs) > 0 {
+	if excludedChunks := params.ExcludedChunkIDs(); len(excludedChunks) > 0 {
 		mustNot = append(mustNot, types.Query{Terms: &types.TermsQuery{
-			TermsQuery: map[string]types.TermsQueryField{e.idField("chunk_id"): params.ExcludeChunkIDs},
+			TermsQuery: map[string]types.TermsQueryField{e.idField("chunk_id"): excludedChunks},
 		}})
 	}
 	return []types.Query{{Bool: &types.BoolQuery{Must: must, MustNot: mustNot}}}
@@ -0,0 +1,86 @@
+package milvus
+
+import (
+	"os"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	envMilvusAnalyzerType      = "MILVUS_ANALYZER_TYPE"
+	envMilvusAnalyzerStopWords = "MILVUS_ANALYZER_STOP_WORDS"
+	// jiebaDefaultDict keeps jieba's built-in dictionary loaded when custom
+	// terms are supplied; without it only the custom terms would be known.
+	jiebaDefaultDict = "_default_"
+)
+
+// analyzerConfig is the resolved full-text analyzer setup for the content field.
+type analyzerConfig struct {
+	analyzerType string
+	stopWords    []string
+	dictionary   []string
+}
+
+// resolveAnalyzerConfig reads the analyzer fields from indexCfg, falling back
+// to MILVUS_ANALYZER_TYPE / MILVUS_ANALYZER_STOP_WORDS (comma separated) for
+// env-configured stores that have no IndexConfig.
+func resolveAnalyzerConfig(indexCfg *types.IndexConfig) analyzerConfig {
+	cfg := analyzerConfig{
+		analyzerType: indexCfg.GetAnalyzerType(strings.ToLower(strings.TrimSpace(os.Getenv(envMilvusAnalyzerType)))),
+	}
+	if indexCfg != nil {
+		cfg.stopWords = indexCfg.AnalyzerStopWords
+		cfg.dictionary = indexCfg.AnalyzerDictionary
+	}
+	if len(cfg.stopWords) == 0 {
+		for _, w := range strings.Split(os.Getenv(envMilvusAnalyzerStopWords), ",") {
+			if w = strings.TrimSpace(w); w != "" {
+				cfg.stopWords = append(cfg.stopWords, w)
+			}
+		}
+	}
+	return cfg
+}
+
+// params builds the Milvus analyzer_params for the content field.
+// Returns nil when nothing is configured so the field keeps Milvus' default
+// standard analyzer, matching collections created before this was configurable.
+// ref: https://milvus.io/docs/analyzer-overview.md
+func (a analyzerConfig) params() map[string]any {
+	if a.analyzerType == "" && len(a.stopWords) == 0 {
+		return nil
+	}
+
+	var tokenizer any
+	filters := make([]any, 0, 3)
+	switch a.analyzerType {
+	case types.AnalyzerTypeJieba:
+		dict := append([]string{jiebaDefaultDict}, a.dictionary...)
+		tokenizer = map[string]any{
+			"type": "jieba",
+			"dict": dict,
+			"mode": "search",
+			"hmm":  true,
+		}
+		// Chinese text has no case, but mixed-language chunks still benefit
+		// from lower-casing English product names and acronyms.
+		filters = append(filters, "lowercase")
+	case types.AnalyzerTypeICU:
+		tokenizer = "icu"
+		filters = append(filters, "lowercase")
+	default:
+		tokenizer = "standard"
+		filters = append(filters, "lowercase")
+	}
+	if len(a.stopWords) > 0 {
+		filters = append(filters, map[string]any{
+			"type":       "stop",
+			"stop_words": a.stopWords,
+		})
+	}
+	return map[string]any{
+		"tokenizer": tokenizer,
+		"filter":    filters,
+	}
+}
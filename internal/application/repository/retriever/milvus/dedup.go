@@ -0,0 +1,127 @@
+package milvus
+
+import (
+	"context"
+	"fmt"
+
+	client "github.com/milvus-io/milvus/client/v2/milvusclient"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// DeduplicateIndices repairs rows written while Save still generated a
+// random primary key per upsert, when a retried write left several rows for
+// the same (chunk_id, source_id, source_type). Every row whose ID differs
+// from types.IndexRowID is upserted under that ID and its old row deleted,
+// so duplicates collapse into a single row and later retries stay idempotent.
+//
+// The run is safe to repeat and to interrupt: the upsert precedes the
+// delete, so a crash leaves at worst a duplicate that the next run removes.
+// Removed is derived from row counts before and after, so concurrent
+// ingestion into a collection skews it; run with ingestion paused for exact
+// numbers.
+func (m *milvusRepository) DeduplicateIndices(ctx context.Context) (*types.DedupReport, error) {
+	log := logger.GetLogger(ctx)
+
+	collections, err := m.client.ListCollections(ctx, client.NewListCollectionOption())
+	if err != nil {
+		log.Errorf("[Milvus] Failed to list collections: %v", err)
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	report := &types.DedupReport{EngineType: types.MilvusRetrieverEngineType}
+	for _, collectionName := range collections {
+		dimension, _, ok := m.parseCollectionName(collectionName)
+		if !ok {
+			continue
+		}
+		result := m.dedupCollection(ctx, collectionName, dimension)
+		if result.Error != "" {
+			log.Errorf("[Milvus] Failed to deduplicate collection %s: %s", collectionName, result.Error)
+		}
+		report.Collections = append(report.Collections, result)
+	}
+
+	log.Infof("[Milvus] Deduplication completed, collections: %d", len(report.Collections))
+	return report, nil
+}
+
+func (m *milvusRepository) dedupCollection(ctx context.Context,
+	collectionName string, dimension int,
+) types.CollectionDedupResult {
+	result := types.CollectionDedupResult{Collection: collectionName, Dimension: dimension}
+	if err := m.ensureCollection(ctx, collectionName, dimension); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	before, err := m.countRows(ctx, collectionName)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	batchSize := reindexBatchSize
+	lastID := ""
+	for {
+		embeddings, count, err := m.searchByFilter(ctx, collectionName, &universalFilterCondition{
+			Field:    fieldID,
+			Operator: operatorGreaterThan,
+			Value:    lastID,
+		}, &batchSize, nil)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		if len(embeddings) == 0 {
+			break
+		}
+		result.Scanned += len(embeddings)
+
+		var rekeyed []*MilvusVectorEmbedding
+		var staleIDs []string
+		positionByID := make(map[string]int)
+		for _, embedding := range embeddings {
+			if embedding.ID > lastID {
+				lastID = embedding.ID
+			}
+			rowID := types.IndexRowID(embedding.ChunkID, embedding.SourceID, types.SourceType(embedding.SourceType))
+			if embedding.ID == rowID {
+				continue
+			}
+			staleIDs = append(staleIDs, embedding.ID)
+			row := embedding.MilvusVectorEmbedding
+			row.ID = rowID
+			if pos, ok := positionByID[rowID]; ok {
+				rekeyed[pos] = &row
+				continue
+			}
+			positionByID[rowID] = len(rekeyed)
+			rekeyed = append(rekeyed, &row)
+		}
+		if len(rekeyed) > 0 {
+			if _, err := m.upsert(ctx, createUpsert(collectionName, rekeyed)); err != nil {
+				result.Error = fmt.Sprintf("upsert rekeyed rows: %v", err)
+				return result
+			}
+			deleteOpt := client.NewDeleteOption(collectionName)
+			deleteOpt.WithStringIDs(fieldID, staleIDs)
+			if _, err := m.delete(ctx, deleteOpt); err != nil {
+				result.Error = fmt.Sprintf("delete stale rows: %v", err)
+				return result
+			}
+			result.Rekeyed += len(staleIDs)
+		}
+		if count < batchSize {
+			break
+		}
+	}
+
+	after, err := m.countRows(ctx, collectionName)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Removed = max(before-after, 0)
+	return result
+}
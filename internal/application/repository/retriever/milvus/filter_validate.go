@@ -0,0 +1,224 @@
+package milvus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// filterFieldTypes is the scalar schema of every collection created by
+// createCollection; filter conditions may only reference these fields.
+var filterFieldTypes = map[string]entity.FieldType{
+	fieldID:              entity.FieldTypeVarChar,
+	fieldContent:         entity.FieldTypeVarChar,
+	fieldSourceID:        entity.FieldTypeVarChar,
+	fieldSourceType:      entity.FieldTypeInt64,
+	fieldChunkID:         entity.FieldTypeVarChar,
+	fieldKnowledgeID:     entity.FieldTypeVarChar,
+	fieldKnowledgeBaseID: entity.FieldTypeVarChar,
+	fieldTagID:           entity.FieldTypeVarChar,
+	fieldIsEnabled:       entity.FieldTypeBool,
+}
+
+// filterOperators are the operators checkFilterCondition accepts.
+var filterOperators = []string{
+	operatorAnd, operatorOr,
+	operatorEqual, operatorNotEqual, operatorGreaterThan, operatorGreaterThanOrEqual,
+	operatorLessThan, operatorLessThanOrEqual, operatorLike, operatorNotLike,
+	operatorIn, operatorNotIn, operatorBetween,
+}
+
+// maxFilterDepth caps and/or nesting. The compiled expression grows with
+// every level, and Milvus parses it recursively.
+const maxFilterDepth = 8
+
+// ValidateFilter parses a universalFilterCondition JSON document, checks it
+// against the collection schema and returns the compiled expression with its
+// parameter bindings. Problems with the filter itself are reported as
+// Issues; the error return is reserved for failures of the check.
+func (m *milvusRepository) ValidateFilter(_ context.Context, raw json.RawMessage) (*types.FilterValidation, error) {
+	result := &types.FilterValidation{EngineType: types.MilvusRetrieverEngineType}
+
+	var cond universalFilterCondition
+	if err := json.Unmarshal(raw, &cond); err != nil {
+		result.Issues = append(result.Issues, types.FilterIssue{Message: "invalid filter: " + err.Error()})
+		return result, nil
+	}
+	result.Issues = checkFilterCondition(&cond, "")
+	if len(result.Issues) > 0 {
+		return result, nil
+	}
+
+	converted, err := m.filter.Convert(&cond)
+	if err != nil {
+		result.Issues = append(result.Issues, types.FilterIssue{Message: err.Error()})
+		return result, nil
+	}
+	result.Valid = true
+	result.Expression = converted.exprStr
+	result.Params = converted.params
+	return result, nil
+}
+
+// checkFilterCondition walks cond and reports every schema mismatch. JSON
+// numbers bound to int64 fields are converted in place so the compiled
+// parameters carry the type Milvus expects.
+func checkFilterCondition(cond *universalFilterCondition, path string) []types.FilterIssue {
+	issue := func(field, format string, args ...any) []types.FilterIssue {
+		return []types.FilterIssue{{Path: path, Field: field, Message: fmt.Sprintf(format, args...)}}
+	}
+	if cond == nil {
+		return issue("", "condition is empty")
+	}
+
+	switch cond.Operator {
+	case operatorAnd, operatorOr:
+		if depth := strings.Count(path, "[") + 1; depth > maxFilterDepth {
+			return issue("", "and/or conditions nest deeper than %d levels", maxFilterDepth)
+		}
+		children, ok := cond.Value.([]*universalFilterCondition)
+		if !ok || len(children) == 0 {
+			return issue("", "operator %q requires a non-empty array of conditions", cond.Operator)
+		}
+		var issues []types.FilterIssue
+		for i, child := range children {
+			issues = append(issues, checkFilterCondition(child, childFilterPath(path, i))...)
+		}
+		return issues
+
+	case operatorEqual, operatorNotEqual, operatorGreaterThan, operatorGreaterThanOrEqual,
+		operatorLessThan, operatorLessThanOrEqual, operatorLike, operatorNotLike:
+		fieldType, msg := lookupFilterField(cond.Field)
+		if msg != "" {
+			return issue(cond.Field, "%s", msg)
+		}
+		if fieldType == entity.FieldTypeBool && cond.Operator != operatorEqual && cond.Operator != operatorNotEqual {
+			return issue(cond.Field, "operator %q is not supported on bool field", cond.Operator)
+		}
+		if (cond.Operator == operatorLike || cond.Operator == operatorNotLike) && fieldType != entity.FieldTypeVarChar {
+			return issue(cond.Field, "operator %q requires a string field", cond.Operator)
+		}
+		value, msg := coerceFilterValue(fieldType, cond.Value)
+		if msg != "" {
+			return issue(cond.Field, "%s", msg)
+		}
+		cond.Value = value
+		return nil
+
+	case operatorIn, operatorNotIn, operatorBetween:
+		fieldType, msg := lookupFilterField(cond.Field)
+		if msg != "" {
+			return issue(cond.Field, "%s", msg)
+		}
+		values := reflect.ValueOf(cond.Value)
+		if cond.Value == nil || values.Kind() != reflect.Slice {
+			return issue(cond.Field, "operator %q requires an array value", cond.Operator)
+		}
+		if cond.Operator == operatorBetween {
+			if values.Len() != 2 {
+				return issue(cond.Field, "operator %q requires exactly two values", cond.Operator)
+			}
+			if fieldType == entity.FieldTypeBool {
+				return issue(cond.Field, "operator %q is not supported on bool field", cond.Operator)
+			}
+		} else if values.Len() == 0 {
+			return issue(cond.Field, "operator %q requires at least one value", cond.Operator)
+		}
+		coerced := make([]any, values.Len())
+		for i := range coerced {
+			v, msg := coerceFilterValue(fieldType, values.Index(i).Interface())
+			if msg != "" {
+				return issue(cond.Field, "value[%d]: %s", i, msg)
+			}
+			coerced[i] = v
+		}
+		cond.Value = coerced
+		return nil
+
+	default:
+		return issue(cond.Field, "unsupported operator %q", cond.Operator)
+	}
+}
+
+// lookupFilterField returns the schema type of field, or a message
+// explaining why it cannot be filtered on.
+func lookupFilterField(field string) (entity.FieldType, string) {
+	if field == "" {
+		return entity.FieldTypeNone, "field is required"
+	}
+	if field == fieldEmbedding || field == fieldContentSparse {
+		return entity.FieldTypeNone, "vector field cannot be used in a filter"
+	}
+	fieldType, ok := filterFieldTypes[field]
+	if !ok {
+		names := make([]string, 0, len(filterFieldTypes))
+		for name := range filterFieldTypes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return entity.FieldTypeNone, fmt.Sprintf("unknown field; filterable fields: %s", strings.Join(names, ", "))
+	}
+	return fieldType, ""
+}
+
+// coerceFilterValue checks that v fits fieldType and returns it in the Go
+// type bound to Milvus for that field.
+func coerceFilterValue(fieldType entity.FieldType, v any) (any, string) {
+	switch fieldType {
+	case entity.FieldTypeVarChar:
+		if s, ok := v.(string); ok {
+			return s, ""
+		}
+		return nil, fmt.Sprintf("expected string, got %s", filterValueKind(v))
+	case entity.FieldTypeBool:
+		if b, ok := v.(bool); ok {
+			return b, ""
+		}
+		return nil, fmt.Sprintf("expected bool, got %s", filterValueKind(v))
+	case entity.FieldTypeInt64:
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return rv.Int(), ""
+		case reflect.Float32, reflect.Float64:
+			if f := rv.Float(); f == math.Trunc(f) && math.Abs(f) < 1<<53 {
+				return int64(f), ""
+			}
+		}
+		return nil, fmt.Sprintf("expected integer, got %s", filterValueKind(v))
+	}
+	return nil, "unsupported field type"
+}
+
+func filterValueKind(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case float32, float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func childFilterPath(path string, i int) string {
+	if path == "" {
+		return fmt.Sprintf("value[%d]", i)
+	}
+	return fmt.Sprintf("%s.value[%d]", path, i)
+}
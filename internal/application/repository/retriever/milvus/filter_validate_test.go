@@ -0,0 +1,83 @@
+package milvus
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateFilterCompilesValidCondition(t *testing.T) {
+	repo := &milvusRepository{}
+	raw := json.RawMessage(`{"operator": "and", "value": [
+		{"field": "knowledge_base_id", "operator": "in", "value": ["kb-1", "kb-2"]},
+		{"field": "source_type", "operator": "eq", "value": 1},
+		{"field": "is_enabled", "operator": "eq", "value": true}
+	]}`)
+
+	got, err := repo.ValidateFilter(context.Background(), raw)
+	require.NoError(t, err)
+	require.True(t, got.Valid, "issues: %+v", got.Issues)
+	require.Equal(t,
+		"((knowledge_base_id in {knowledge_base_id_1}) and (source_type == {source_type_2})) and (is_enabled == {is_enabled_3})",
+		got.Expression)
+	require.Equal(t, int64(1), got.Params["source_type_2"])
+	require.Equal(t, []any{"kb-1", "kb-2"}, got.Params["knowledge_base_id_1"])
+}
+
+func TestValidateFilterReportsSchemaMismatches(t *testing.T) {
+	repo := &milvusRepository{}
+	raw := json.RawMessage(`{"operator": "or", "value": [
+		{"field": "author", "operator": "eq", "value": "x"},
+		{"field": "source_type", "operator": "eq", "value": "1"},
+		{"field": "is_enabled", "operator": "gt", "value": true},
+		{"field": "embedding", "operator": "eq", "value": 1},
+		{"field": "chunk_id", "operator": "between", "value": ["a"]}
+	]}`)
+
+	got, err := repo.ValidateFilter(context.Background(), raw)
+	require.NoError(t, err)
+	require.False(t, got.Valid)
+	require.Empty(t, got.Expression)
+	require.Len(t, got.Issues, 5)
+	require.Equal(t, "value[0]", got.Issues[0].Path)
+	require.Equal(t, "author", got.Issues[0].Field)
+	require.Contains(t, got.Issues[0].Message, "unknown field")
+	require.Contains(t, got.Issues[1].Message, "expected integer, got string")
+	require.Contains(t, got.Issues[2].Message, "not supported on bool field")
+	require.Contains(t, got.Issues[3].Message, "vector field")
+	require.Contains(t, got.Issues[4].Message, "exactly two values")
+}
+
+func TestValidateFilterRejectsMalformedJSON(t *testing.T) {
+	repo := &milvusRepository{}
+	got, err := repo.ValidateFilter(context.Background(), json.RawMessage(`{"operator": "and", "value": {}}`))
+	require.NoError(t, err)
+	require.False(t, got.Valid)
+	require.Len(t, got.Issues, 1)
+	require.Contains(t, got.Issues[0].Message, "requires an array of conditions")
+}
+
+func TestValidateFilterRejectsDeepNesting(t *testing.T) {
+	repo := &milvusRepository{}
+	leaf := `{"field": "tag_id", "operator": "eq", "value": "t"}`
+	nest := func(levels int) json.RawMessage {
+		cond := leaf
+		for range levels {
+			cond = `{"operator": "and", "value": [` + cond + `]}`
+		}
+		return json.RawMessage(cond)
+	}
+
+	got, err := repo.ValidateFilter(context.Background(), nest(maxFilterDepth))
+	require.NoError(t, err)
+	require.True(t, got.Valid, "issues: %+v", got.Issues)
+
+	got, err = repo.ValidateFilter(context.Background(), nest(maxFilterDepth+1))
+	require.NoError(t, err)
+	require.False(t, got.Valid)
+	require.Len(t, got.Issues, 1)
+	require.Contains(t, got.Issues[0].Message, "deeper than")
+	require.Equal(t, repo.Capabilities().MaxFilterDepth, maxFilterDepth)
+}
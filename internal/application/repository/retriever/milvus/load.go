@@ -0,0 +1,224 @@
+package milvus
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/milvus-io/milvus/client/v2/entity"
+	client "github.com/milvus-io/milvus/client/v2/milvusclient"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// envMilvusLoadPolicy selects the load policy for env-configured stores
+	// without an IndexConfig; see types.LoadPolicyEager.
+	envMilvusLoadPolicy = "MILVUS_LOAD_POLICY"
+	// loadTimeout bounds a single background LoadCollection call.
+	loadTimeout = 10 * time.Minute
+	// mmapEnabledKey is the collection property that memory-maps raw data
+	// and indexes instead of loading them into memory.
+	mmapEnabledKey = "mmap.enabled"
+)
+
+// resolveLoadPolicy reads load_policy from indexCfg, falling back to
+// MILVUS_LOAD_POLICY and then to eager. Unknown env values fall back to
+// eager; IndexConfig values are validated before they are stored.
+func resolveLoadPolicy(indexCfg *types.IndexConfig) string {
+	switch policy := indexCfg.GetLoadPolicy(os.Getenv(envMilvusLoadPolicy)); policy {
+	case types.LoadPolicyLazy, types.LoadPolicyMmap:
+		return policy
+	default:
+		return types.LoadPolicyEager
+	}
+}
+
+// collectionLoad is one LoadCollection call. done is closed when the call
+// returns; err is set before that.
+type collectionLoad struct {
+	done chan struct{}
+	err  error
+}
+
+// wait blocks until the load finishes or ctx is done.
+func (l *collectionLoad) wait(ctx context.Context) error {
+	select {
+	case <-l.done:
+		return l.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// failed reports whether the load finished with an error.
+func (l *collectionLoad) failed() bool {
+	select {
+	case <-l.done:
+		return l.err != nil
+	default:
+		return false
+	}
+}
+
+// startLoad loads collectionName in the background. Concurrent callers share
+// the in-flight load; a failed load is replaced so the next caller retries.
+// The load runs detached from any request context so an abandoned request
+// does not cancel it for the others waiting on it.
+func (m *milvusRepository) startLoad(collectionName string) *collectionLoad {
+	load := &collectionLoad{done: make(chan struct{})}
+	for {
+		existing, loaded := m.loads.LoadOrStore(collectionName, load)
+		if !loaded {
+			break
+		}
+		prev := existing.(*collectionLoad)
+		if !prev.failed() {
+			return prev
+		}
+		if m.loads.CompareAndSwap(collectionName, prev, load) {
+			break
+		}
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), loadTimeout)
+		defer cancel()
+		load.err = m.loadCollection(ctx, collectionName)
+		close(load.done)
+	}()
+	return load
+}
+
+// loadCollection loads collectionName with the configured replica number
+// and waits until Milvus reports it loaded.
+func (m *milvusRepository) loadCollection(ctx context.Context, collectionName string) error {
+	log := logger.GetLogger(ctx)
+	loadOpt := client.NewLoadCollectionOption(collectionName)
+	if m.replicaNumber > 0 {
+		loadOpt = loadOpt.WithReplica(m.replicaNumber)
+	}
+	loadTask, err := m.client.LoadCollection(ctx, loadOpt)
+	if err != nil {
+		log.Errorf("[Milvus] Failed to load collection %s: %v", collectionName, err)
+		return fmt.Errorf("failed to load collection: %w", err)
+	}
+	if err := loadTask.Await(ctx); err != nil {
+		log.Errorf("[Milvus] Failed to await load collection %s: %v", collectionName, err)
+		return fmt.Errorf("failed to await load collection: %w", err)
+	}
+	return nil
+}
+
+// forgetCollection drops the cached state of a collection that was dropped
+// or replaced, so the next ensureCollection creates and loads it again.
+func (m *milvusRepository) forgetCollection(collectionName string) {
+	m.initializedCollections.Delete(collectionName)
+	m.loads.Delete(collectionName)
+}
+
+// awaitLoad waits for a load of collectionName started by this process, so
+// a search never runs against a collection that is still loading under the
+// lazy policy or the warm-up. Collections without a tracked load were
+// loaded before this process started and are searched directly.
+func (m *milvusRepository) awaitLoad(ctx context.Context, collectionName string) error {
+	existing, ok := m.loads.Load(collectionName)
+	if !ok {
+		return nil
+	}
+	load := existing.(*collectionLoad)
+	if load.failed() {
+		load = m.startLoad(collectionName)
+	}
+	return load.wait(ctx)
+}
+
+// ownedCollections lists the collections this repository manages, skipping
+// reindex staging/backup collections and collections of other base names.
+func (m *milvusRepository) ownedCollections(ctx context.Context) ([]string, error) {
+	collections, err := m.client.ListCollections(ctx, client.NewListCollectionOption())
+	if err != nil {
+		logger.GetLogger(ctx).Errorf("[Milvus] Failed to list collections: %v", err)
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	var names []string
+	for _, collectionName := range collections {
+		if _, _, ok := m.parseCollectionName(collectionName); ok {
+			names = append(names, collectionName)
+		}
+	}
+	return names, nil
+}
+
+// warmUp loads every existing collection in the background so the first
+// request after startup does not pay for LoadCollection. Run by the eager
+// and mmap policies; failures are logged and retried on first use.
+func (m *milvusRepository) warmUp(ctx context.Context) {
+	log := logger.GetLogger(ctx)
+	collections, err := m.ownedCollections(ctx)
+	if err != nil {
+		log.Warnf("[Milvus] Skipping collection warm-up: %v", err)
+		return
+	}
+
+	var g errgroup.Group
+	g.SetLimit(collectionConcurrency)
+	for _, collectionName := range collections {
+		g.Go(func() error {
+			if err := m.startLoad(collectionName).wait(ctx); err != nil {
+				log.Warnf("[Milvus] Warm-up of collection %s failed: %v", collectionName, err)
+				return nil
+			}
+			m.initializedCollections.Store(collectionName, true)
+			return nil
+		})
+	}
+	_ = g.Wait()
+	log.Infof("[Milvus] Collection warm-up finished, collections: %d", len(collections))
+}
+
+// LoadStates reports the load state of every collection this repository
+// manages; see interfaces.LoadStateReporter.
+func (m *milvusRepository) LoadStates(ctx context.Context) (*types.LoadStateReport, error) {
+	collections, err := m.ownedCollections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &types.LoadStateReport{
+		EngineType:  types.MilvusRetrieverEngineType,
+		LoadPolicy:  m.loadPolicy,
+		Ready:       true,
+		Collections: make([]types.CollectionLoadState, 0, len(collections)),
+	}
+	for _, collectionName := range collections {
+		dimension, _, _ := m.parseCollectionName(collectionName)
+		state := types.CollectionLoadState{Collection: collectionName, Dimension: dimension}
+
+		loadState, err := m.client.GetLoadState(ctx, client.NewGetLoadStateOption(collectionName))
+		if err != nil {
+			logger.GetLogger(ctx).Errorf("[Milvus] Failed to get load state of %s: %v", collectionName, err)
+			return nil, fmt.Errorf("failed to get load state: %w", err)
+		}
+		switch loadState.State {
+		case entity.LoadStateLoaded:
+			state.State, state.Progress = types.CollectionLoaded, 100
+		case entity.LoadStateLoading:
+			state.State, state.Progress = types.CollectionLoading, loadState.Progress
+		default:
+			state.State = types.CollectionNotLoaded
+		}
+		if existing, ok := m.loads.Load(collectionName); ok {
+			if load := existing.(*collectionLoad); load.failed() {
+				state.Error = load.err.Error()
+			}
+		}
+
+		report.Ready = report.Ready && state.State == types.CollectionLoaded
+		report.Collections = append(report.Collections, state)
+	}
+	return report, nil
+}
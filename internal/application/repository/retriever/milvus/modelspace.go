@@ -0,0 +1,248 @@
+package milvus
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	client "github.com/milvus-io/milvus/client/v2/milvusclient"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// migrateKBChunkSize bounds the knowledge_base_id IN (...) list of a
+	// single migration query.
+	migrateKBChunkSize = 100
+	countOutputField   = "count(*)"
+)
+
+// modelSpacePattern matches the model suffix produced by modelSpaceID.
+var modelSpacePattern = regexp.MustCompile(`^m[0-9a-f]{16}$`)
+
+// modelSpaceID derives a collection-name-safe token from an embedding model
+// ID. Model IDs may contain characters Milvus rejects in collection names, so
+// a truncated SHA-256 is used instead of the raw ID.
+func modelSpaceID(modelID string) string {
+	sum := sha256.Sum256([]byte(modelID))
+	return "m" + hex.EncodeToString(sum[:])[:16]
+}
+
+// getModelCollectionName returns <base>_<dim>_<modelSpaceID> so that two
+// embedding models with the same dimension never share a vector space. An
+// empty model ID maps to the legacy per-dimension collection.
+func (m *milvusRepository) getModelCollectionName(modelID string, dimension int) string {
+	if modelID == "" {
+		return m.getCollectionName(dimension)
+	}
+	return fmt.Sprintf("%s_%d_%s", m.collectionBaseName, dimension, modelSpaceID(modelID))
+}
+
+// parseCollectionName returns the dimension and model space encoded in a
+// collection name produced by getCollectionName or getModelCollectionName.
+// modelSpace is empty for legacy collections; ok is false for collections we
+// do not own, including reindex staging and backup collections.
+func (m *milvusRepository) parseCollectionName(collectionName string) (dimension int, modelSpace string, ok bool) {
+	suffix, ok := strings.CutPrefix(collectionName, m.collectionBaseName+"_")
+	if !ok {
+		return 0, "", false
+	}
+	dimStr, modelSpace, hasModel := strings.Cut(suffix, "_")
+	if hasModel && !modelSpacePattern.MatchString(modelSpace) {
+		return 0, "", false
+	}
+	dimension, err := strconv.Atoi(dimStr)
+	if err != nil || dimension <= 0 {
+		return 0, "", false
+	}
+	return dimension, modelSpace, true
+}
+
+// collectionsForDimension lists the existing collections of the given
+// dimension: the legacy collection and every per-model collection.
+func (m *milvusRepository) collectionsForDimension(ctx context.Context, dimension int) ([]string, error) {
+	collections, err := m.client.ListCollections(ctx, client.NewListCollectionOption())
+	if err != nil {
+		logger.GetLogger(ctx).Errorf("[Milvus] Failed to list collections: %v", err)
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+	var names []string
+	for _, collectionName := range collections {
+		if dim, _, ok := m.parseCollectionName(collectionName); ok && dim == dimension {
+			names = append(names, collectionName)
+		}
+	}
+	return names, nil
+}
+
+// MigrateModelSpaces moves rows of the given knowledge bases out of the
+// legacy per-dimension collections into the collection of their embedding
+// model. kbModels maps knowledge base ID to embedding model ID.
+//
+// Rows are upserted into the model collection before they are deleted from
+// the legacy one, so an interrupted run leaves duplicates (which search
+// tolerates) rather than losing rows, and can simply be re-run. A legacy
+// collection that ends up empty is dropped. Rows of knowledge bases not in
+// kbModels (e.g. other tenants sharing an env store) are left in place.
+func (m *milvusRepository) MigrateModelSpaces(ctx context.Context,
+	kbModels map[string]string,
+) (*types.ModelSpaceMigrationReport, error) {
+	log := logger.GetLogger(ctx)
+
+	collections, err := m.client.ListCollections(ctx, client.NewListCollectionOption())
+	if err != nil {
+		log.Errorf("[Milvus] Failed to list collections: %v", err)
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	kbsByModel := make(map[string][]string)
+	for kbID, modelID := range kbModels {
+		if modelID != "" {
+			kbsByModel[modelID] = append(kbsByModel[modelID], kbID)
+		}
+	}
+
+	report := &types.ModelSpaceMigrationReport{EngineType: types.MilvusRetrieverEngineType}
+	for _, collectionName := range collections {
+		dimension, modelSpace, ok := m.parseCollectionName(collectionName)
+		if !ok || modelSpace != "" {
+			continue
+		}
+		result := m.migrateLegacyCollection(ctx, collectionName, dimension, kbsByModel)
+		if result.Error != "" {
+			log.Errorf("[Milvus] Failed to migrate collection %s: %s", collectionName, result.Error)
+		}
+		report.Collections = append(report.Collections, result)
+	}
+
+	log.Infof("[Milvus] Model space migration completed, collections: %d", len(report.Collections))
+	return report, nil
+}
+
+func (m *milvusRepository) migrateLegacyCollection(ctx context.Context,
+	collectionName string, dimension int, kbsByModel map[string][]string,
+) types.ModelSpaceMigrationResult {
+	result := types.ModelSpaceMigrationResult{Collection: collectionName, Dimension: dimension}
+	if err := m.ensureCollection(ctx, collectionName, dimension); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// Deterministic order keeps logs and partial failures reproducible.
+	modelIDs := make([]string, 0, len(kbsByModel))
+	for modelID := range kbsByModel {
+		modelIDs = append(modelIDs, modelID)
+	}
+	sort.Strings(modelIDs)
+
+	for _, modelID := range modelIDs {
+		target := m.getModelCollectionName(modelID, dimension)
+		kbIDs := kbsByModel[modelID]
+		for start := 0; start < len(kbIDs); start += migrateKBChunkSize {
+			end := min(start+migrateKBChunkSize, len(kbIDs))
+			moved, err := m.moveRows(ctx, collectionName, target, dimension, kbIDs[start:end])
+			result.Migrated += moved
+			if err != nil {
+				result.Error = fmt.Sprintf("migrate to %s: %v", target, err)
+				return result
+			}
+		}
+	}
+
+	remaining, err := m.countRows(ctx, collectionName)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Remaining = remaining
+	if remaining == 0 {
+		if err := m.dropCollectionIfExists(ctx, collectionName); err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		m.forgetCollection(collectionName)
+		result.Dropped = true
+	}
+	return result
+}
+
+// moveRows copies the rows of kbIDs from source to target in primary-key
+// order and deletes each batch from source once it is written.
+func (m *milvusRepository) moveRows(ctx context.Context,
+	source, target string, dimension int, kbIDs []string,
+) (int, error) {
+	batchSize := reindexBatchSize
+	moved := 0
+	lastID := ""
+	for {
+		embeddings, count, err := m.searchByFilter(ctx, source, &universalFilterCondition{
+			Operator: operatorAnd,
+			Value: []*universalFilterCondition{
+				{Field: fieldKnowledgeBaseID, Operator: operatorIn, Value: kbIDs},
+				{Field: fieldID, Operator: operatorGreaterThan, Value: lastID},
+			},
+		}, &batchSize, nil)
+		if err != nil {
+			return moved, err
+		}
+		if len(embeddings) == 0 {
+			break
+		}
+		// Only create the target once there is something to put in it.
+		if moved == 0 {
+			if err := m.ensureCollection(ctx, target, dimension); err != nil {
+				return moved, err
+			}
+		}
+		rows := make([]*MilvusVectorEmbedding, 0, len(embeddings))
+		ids := make([]string, 0, len(embeddings))
+		for _, embedding := range embeddings {
+			rows = append(rows, &embedding.MilvusVectorEmbedding)
+			ids = append(ids, embedding.ID)
+			if embedding.ID > lastID {
+				lastID = embedding.ID
+			}
+		}
+		if _, err := m.upsert(ctx, createUpsert(target, rows)); err != nil {
+			return moved, err
+		}
+		deleteOpt := client.NewDeleteOption(source)
+		deleteOpt.WithStringIDs(fieldID, ids)
+		if _, err := m.delete(ctx, deleteOpt); err != nil {
+			return moved, fmt.Errorf("delete migrated rows: %w", err)
+		}
+		moved += len(rows)
+		if count < batchSize {
+			break
+		}
+	}
+	return moved, nil
+}
+
+// countRows returns the number of rows in collectionName.
+func (m *milvusRepository) countRows(ctx context.Context, collectionName string) (int, error) {
+	if err := m.awaitLoad(ctx, collectionName); err != nil {
+		return 0, fmt.Errorf("count rows of %s: %w", collectionName, err)
+	}
+	queryOpt := client.NewQueryOption(collectionName)
+	queryOpt.WithOutputFields(countOutputField)
+	resultSet, err := m.query(ctx, collectionName, queryOpt)
+	if err != nil {
+		return 0, fmt.Errorf("count rows of %s: %w", collectionName, err)
+	}
+	col := resultSet.GetColumn(countOutputField)
+	if col == nil || col.Len() == 0 {
+		return 0, fmt.Errorf("count rows of %s: empty result", collectionName)
+	}
+	n, err := col.GetAsInt64(0)
+	if err != nil {
+		return 0, fmt.Errorf("count rows of %s: %w", collectionName, err)
+	}
+	return int(n), nil
+}
@@ -0,0 +1,168 @@
+package milvus
+
+import (
+	"context"
+	"fmt"
+
+	client "github.com/milvus-io/milvus/client/v2/milvusclient"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	reindexBatchSize = 256
+	// Suffixes for the staging / rollback collections used during reindex.
+	// Neither parses as an owned collection name, so the collection
+	// naming helpers never collide with them.
+	reindexStagingSuffix = "_reindex"
+	reindexBackupSuffix  = "_reindex_old"
+)
+
+// ReindexCollections rebuilds every collection owned by this repository so the
+// content field picks up the currently configured analyzer. Milvus fixes the
+// analyzer at CreateCollection time, so the only way to re-tokenize existing
+// rows is to copy them into a freshly created collection and let the BM25
+// function recompute content_sparse on insert. Embeddings are copied as-is.
+//
+// Per collection: create <name>_reindex, copy all rows, rename <name> to
+// <name>_reindex_old, rename the staging collection to <name>, then drop the
+// backup. Rows written to <name> while the copy runs are not carried over,
+// so run this during a maintenance window with ingestion paused.
+//
+// A failure on one collection is recorded in the report and does not stop
+// the others; the original collection is left untouched unless the swap
+// itself fails half-way, in which case the backup collection is kept.
+func (m *milvusRepository) ReindexCollections(ctx context.Context) (*types.ReindexReport, error) {
+	log := logger.GetLogger(ctx)
+
+	collections, err := m.client.ListCollections(ctx, client.NewListCollectionOption())
+	if err != nil {
+		log.Errorf("[Milvus] Failed to list collections: %v", err)
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	report := &types.ReindexReport{EngineType: types.MilvusRetrieverEngineType}
+	for _, collectionName := range collections {
+		dimension, _, ok := m.parseCollectionName(collectionName)
+		if !ok {
+			continue
+		}
+		result := types.CollectionReindexResult{Collection: collectionName, Dimension: dimension}
+		rows, err := m.reindexCollection(ctx, collectionName, dimension)
+		result.Rows = rows
+		if err != nil {
+			log.Errorf("[Milvus] Failed to reindex collection %s: %v", collectionName, err)
+			result.Error = err.Error()
+		}
+		report.Collections = append(report.Collections, result)
+	}
+
+	log.Infof("[Milvus] Reindex completed, collections: %d", len(report.Collections))
+	return report, nil
+}
+
+func (m *milvusRepository) reindexCollection(ctx context.Context, collectionName string, dimension int) (int, error) {
+	log := logger.GetLogger(ctx)
+	stagingName := collectionName + reindexStagingSuffix
+	backupName := collectionName + reindexBackupSuffix
+
+	// The source must be loaded to be queried.
+	if err := m.ensureCollection(ctx, collectionName, dimension); err != nil {
+		return 0, err
+	}
+
+	// Drop a staging collection left behind by an interrupted run.
+	if err := m.dropCollectionIfExists(ctx, stagingName); err != nil {
+		return 0, err
+	}
+	if err := m.createCollection(ctx, stagingName, dimension); err != nil {
+		return 0, err
+	}
+
+	copied, err := m.copyCollection(ctx, collectionName, stagingName)
+	if err != nil {
+		if dropErr := m.dropCollectionIfExists(ctx, stagingName); dropErr != nil {
+			log.Warnf("[Milvus] Failed to drop staging collection %s: %v", stagingName, dropErr)
+		}
+		return copied, fmt.Errorf("copy rows: %w", err)
+	}
+
+	if err := m.dropCollectionIfExists(ctx, backupName); err != nil {
+		return copied, err
+	}
+	if err := m.client.RenameCollection(ctx, client.NewRenameCollectionOption(collectionName, backupName)); err != nil {
+		return copied, fmt.Errorf("rename %s to %s: %w", collectionName, backupName, err)
+	}
+	if err := m.client.RenameCollection(ctx, client.NewRenameCollectionOption(stagingName, collectionName)); err != nil {
+		// Put the original back so searches keep working.
+		if rbErr := m.client.RenameCollection(ctx, client.NewRenameCollectionOption(backupName, collectionName)); rbErr != nil {
+			log.Errorf("[Milvus] Failed to restore %s from %s: %v", collectionName, backupName, rbErr)
+		}
+		return copied, fmt.Errorf("rename %s to %s: %w", stagingName, collectionName, err)
+	}
+
+	// Force the next ensureCollection to load the rebuilt collection.
+	m.forgetCollection(collectionName)
+	if err := m.ensureCollection(ctx, collectionName, dimension); err != nil {
+		return copied, err
+	}
+	if err := m.dropCollectionIfExists(ctx, backupName); err != nil {
+		log.Warnf("[Milvus] Reindexed %s but failed to drop backup %s: %v", collectionName, backupName, err)
+	}
+
+	log.Infof("[Milvus] Reindexed collection %s, rows: %d", collectionName, copied)
+	return copied, nil
+}
+
+// copyCollection copies every row from source to target in primary-key
+// order. Milvus returns query results sorted by primary key, so an id cursor
+// pages through collections larger than the query offset+limit window that
+// bounds offset pagination.
+func (m *milvusRepository) copyCollection(ctx context.Context, source, target string) (int, error) {
+	batchSize := reindexBatchSize
+	copied := 0
+	lastID := ""
+	for {
+		embeddings, count, err := m.searchByFilter(ctx, source, &universalFilterCondition{
+			Field:    fieldID,
+			Operator: operatorGreaterThan,
+			Value:    lastID,
+		}, &batchSize, nil)
+		if err != nil {
+			return copied, err
+		}
+		if len(embeddings) == 0 {
+			break
+		}
+		rows := make([]*MilvusVectorEmbedding, 0, len(embeddings))
+		for _, embedding := range embeddings {
+			rows = append(rows, &embedding.MilvusVectorEmbedding)
+			if embedding.ID > lastID {
+				lastID = embedding.ID
+			}
+		}
+		if _, err := m.upsert(ctx, createUpsert(target, rows)); err != nil {
+			return copied, err
+		}
+		copied += len(rows)
+		if count < batchSize {
+			break
+		}
+	}
+	return copied, nil
+}
+
+func (m *milvusRepository) dropCollectionIfExists(ctx context.Context, collectionName string) error {
+	has, err := m.client.HasCollection(ctx, client.NewHasCollectionOption(collectionName))
+	if err != nil {
+		return fmt.Errorf("check collection %s: %w", collectionName, err)
+	}
+	if !has {
+		return nil
+	}
+	if err := m.client.DropCollection(ctx, client.NewDropCollectionOption(collectionName)); err != nil {
+		return fmt.Errorf("drop collection %s: %w", collectionName, err)
+	}
+	return nil
+}
@@ -1,22 +1,33 @@
 package milvus
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"maps"
 	"os"
 	"slices"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/milvus-io/milvus/client/v2/column"
 	"github.com/milvus-io/milvus/client/v2/entity"
 	"github.com/milvus-io/milvus/client/v2/index"
 	client "github.com/milvus-io/milvus/client/v2/milvusclient"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/tracing/telemetry"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
 )
 
 const (
@@ -34,8 +45,21 @@ const (
 	fieldIsEnabled        = "is_enabled"
 	fieldID               = "id"
 	fieldContentSparse    = "content_sparse"
+	// collectionConcurrency bounds how many collections one call works on
+	// at the same time.
+	collectionConcurrency = 4
+	// enabledStatusBatchSize bounds the chunk IDs looked up and upserted by
+	// one enabled-status request.
+	enabledStatusBatchSize = 1000
+	// milvusCodeServiceUnimplemented is the Milvus error code of a request
+	// the server does not implement (merr.ErrServiceUnimplemented).
+	milvusCodeServiceUnimplemented = 10
 )
 
+// envMilvusContentStorage selects "full" (default) or "reference" content
+// storage for env-configured stores without an IndexConfig.
+const envMilvusContentStorage = "MILVUS_CONTENT_STORAGE"
+
 var (
 	allFields = []string{fieldID, fieldContent, fieldSourceID, fieldSourceType, fieldChunkID,
 		fieldKnowledgeID, fieldKnowledgeBaseID, fieldTagID, fieldIsEnabled, fieldEmbedding}
@@ -71,23 +95,38 @@ func NewMilvusRetrieveEngineRepository(client *client.Client, indexCfg *types.In
 		metricType:         metricType,
 		shardsNum:          indexCfg.GetShardsNum(0),
 		replicaNumber:      indexCfg.GetReplicaNumber(0),
+		analyzer:           resolveAnalyzerConfig(indexCfg),
+		contentReference:   indexCfg.GetContentStorage(os.Getenv(envMilvusContentStorage)) == types.ContentStorageReference,
+		loadPolicy:         resolveLoadPolicy(indexCfg),
+	}
+	if res.contentReference {
+		log.Info("[Milvus] Content reference mode: chunk content is not stored, keyword retrieval disabled")
+	}
+	if res.analyzer.analyzerType != "" {
+		log.Infof("[Milvus] Using content analyzer: %s", res.analyzer.analyzerType)
+	}
+
+	log.Infof("[Milvus] Using collection load policy: %s", res.loadPolicy)
+	if res.loadPolicy != types.LoadPolicyLazy {
+		go res.warmUp(context.Background())
 	}
 
 	log.Info("[Milvus] Successfully initialized repository")
 	return res
 }
 
-// getCollectionName returns the collection name for a specific dimension
+// getCollectionName returns the legacy collection name for a specific
+// dimension, shared by every embedding model of that dimension.
 func (m *milvusRepository) getCollectionName(dimension int) string {
 	return fmt.Sprintf("%s_%d", m.collectionBaseName, dimension)
 }
 
-// ensureCollection ensures the collection exists for the given dimension
-func (m *milvusRepository) ensureCollection(ctx context.Context, dimension int) error {
-	collectionName := m.getCollectionName(dimension)
-
+// ensureCollection ensures collectionName exists with a vector field of the
+// given dimension and is loaded. Under the lazy policy the load only starts
+// here; writes go ahead while searches wait in awaitLoad.
+func (m *milvusRepository) ensureCollection(ctx context.Context, collectionName string, dimension int) error {
 	// Check cache first
-	if _, ok := m.initializedCollections.Load(dimension); ok {
+	if _, ok := m.initializedCollections.Load(collectionName); ok {
 		return nil
 	}
 
@@ -101,127 +140,208 @@ func (m *milvusRepository) ensureCollection(ctx context.Context, dimension int)
 	}
 
 	if !hasCollection {
-		log.Infof("[Milvus] Creating collection %s with dimension %d", collectionName, dimension)
-
-		// Define schema
-		schema := &entity.Schema{
-			CollectionName: collectionName,
-			Description:    fmt.Sprintf("WeKnora embeddings collection with dimension %d", dimension),
-			AutoID:         false,
-			Fields: []*entity.Field{
-				entity.NewField().
-					WithName(fieldID).
-					WithDataType(entity.FieldTypeVarChar).
-					WithIsPrimaryKey(true).
-					WithMaxLength(1024),
-				entity.NewField().
-					WithName(fieldEmbedding).
-					WithDataType(entity.FieldTypeFloatVector).
-					WithDim(int64(dimension)),
-				entity.NewField().
-					WithName(fieldContent).
-					WithDataType(entity.FieldTypeVarChar).
-					WithMaxLength(65535).
-					WithEnableAnalyzer(true).
-					WithEnableMatch(true),
-				entity.NewField().
-					WithName(fieldContentSparse).
-					WithDataType(entity.FieldTypeSparseVector),
-				entity.NewField().
-					WithName(fieldSourceID).
-					WithDataType(entity.FieldTypeVarChar).
-					WithMaxLength(255),
-				entity.NewField().
-					WithName(fieldSourceType).
-					WithDataType(entity.FieldTypeInt64),
-				entity.NewField().
-					WithName(fieldChunkID).
-					WithDataType(entity.FieldTypeVarChar).
-					WithMaxLength(255),
-				entity.NewField().
-					WithName(fieldKnowledgeID).
-					WithDataType(entity.FieldTypeVarChar).
-					WithMaxLength(255),
-				entity.NewField().
-					WithName(fieldKnowledgeBaseID).
-					WithDataType(entity.FieldTypeVarChar).
-					WithMaxLength(255),
-				entity.NewField().
-					WithName(fieldTagID).
-					WithDataType(entity.FieldTypeVarChar).
-					WithMaxLength(255),
-				entity.NewField().
-					WithName(fieldIsEnabled).
-					WithDataType(entity.FieldTypeBool),
-			},
+		if err := m.createCollection(ctx, collectionName, dimension); err != nil {
+			return err
 		}
+	}
 
-		// Add BM25 function for content sparse vector
-		// ref: https://milvus.io/docs/zh/full-text-search.md
-		schema.WithFunction(entity.NewFunction().
-			WithName("text_bm25_emb").
-			WithInputFields(fieldContent).
-			WithOutputFields(fieldContentSparse).
-			WithType(entity.FunctionTypeBM25))
-
-		indexOpts := make([]client.CreateIndexOption, 0)
-		// hnsw index for embedding field
-		indexOpts = append(indexOpts, client.NewCreateIndexOption(collectionName, fieldEmbedding, index.NewHNSWIndex(m.metricType, 16, 128)))
-		indexOpts = append(indexOpts, client.NewCreateIndexOption(collectionName, fieldContentSparse, index.NewAutoIndex(entity.BM25)))
-		// Create payload indexes for filtering
-		indexFields := []string{fieldChunkID, fieldKnowledgeID, fieldKnowledgeBaseID, fieldSourceID, fieldIsEnabled}
-		for _, fieldName := range indexFields {
-			indexOpts = append(indexOpts, client.NewCreateIndexOption(collectionName, fieldName, index.NewAutoIndex(entity.IP)))
+	load := m.startLoad(collectionName)
+	if m.loadPolicy != types.LoadPolicyLazy {
+		if err := load.wait(ctx); err != nil {
+			return err
 		}
+	}
 
-		// Create collection
-		createOpt := client.NewCreateCollectionOption(collectionName, schema).WithIndexOptions(indexOpts...)
-		if m.shardsNum > 0 {
-			createOpt = createOpt.WithShardNum(int32(m.shardsNum))
-		}
-		err = m.client.CreateCollection(ctx, createOpt)
-		if err != nil {
-			log.Errorf("[Milvus] Failed to create collection: %v", err)
-			return fmt.Errorf("failed to create collection: %w", err)
-		}
+	// Mark as initialized
+	m.initializedCollections.Store(collectionName, true)
+	return nil
+}
 
-		log.Infof("[Milvus] Successfully created collection %s", collectionName)
+// createCollection creates collectionName with the WeKnora schema, the BM25
+// function and all indexes. The content field uses the configured analyzer.
+func (m *milvusRepository) createCollection(ctx context.Context, collectionName string, dimension int) error {
+	log := logger.GetLogger(ctx)
+	log.Infof("[Milvus] Creating collection %s with dimension %d", collectionName, dimension)
+
+	contentField := entity.NewField().
+		WithName(fieldContent).
+		WithDataType(entity.FieldTypeVarChar).
+		WithMaxLength(65535).
+		WithEnableAnalyzer(true).
+		WithEnableMatch(true)
+	if analyzerParams := m.analyzer.params(); analyzerParams != nil {
+		contentField = contentField.WithAnalyzerParams(analyzerParams)
+	}
+
+	// Define schema
+	schema := &entity.Schema{
+		CollectionName: collectionName,
+		Description:    fmt.Sprintf("WeKnora embeddings collection with dimension %d", dimension),
+		AutoID:         false,
+		Fields: []*entity.Field{
+			entity.NewField().
+				WithName(fieldID).
+				WithDataType(entity.FieldTypeVarChar).
+				WithIsPrimaryKey(true).
+				WithMaxLength(1024),
+			entity.NewField().
+				WithName(fieldEmbedding).
+				WithDataType(entity.FieldTypeFloatVector).
+				WithDim(int64(dimension)),
+			contentField,
+			entity.NewField().
+				WithName(fieldContentSparse).
+				WithDataType(entity.FieldTypeSparseVector),
+			entity.NewField().
+				WithName(fieldSourceID).
+				WithDataType(entity.FieldTypeVarChar).
+				WithMaxLength(255),
+			entity.NewField().
+				WithName(fieldSourceType).
+				WithDataType(entity.FieldTypeInt64),
+			entity.NewField().
+				WithName(fieldChunkID).
+				WithDataType(entity.FieldTypeVarChar).
+				WithMaxLength(255),
+			entity.NewField().
+				WithName(fieldKnowledgeID).
+				WithDataType(entity.FieldTypeVarChar).
+				WithMaxLength(255),
+			entity.NewField().
+				WithName(fieldKnowledgeBaseID).
+				WithDataType(entity.FieldTypeVarChar).
+				WithMaxLength(255),
+			entity.NewField().
+				WithName(fieldTagID).
+				WithDataType(entity.FieldTypeVarChar).
+				WithMaxLength(255),
+			entity.NewField().
+				WithName(fieldIsEnabled).
+				WithDataType(entity.FieldTypeBool),
+		},
 	}
 
-	loadOpt := client.NewLoadCollectionOption(collectionName)
-	if m.replicaNumber > 0 {
-		loadOpt = loadOpt.WithReplica(m.replicaNumber)
+	// Add BM25 function for content sparse vector
+	// ref: https://milvus.io/docs/zh/full-text-search.md
+	schema.WithFunction(entity.NewFunction().
+		WithName("text_bm25_emb").
+		WithInputFields(fieldContent).
+		WithOutputFields(fieldContentSparse).
+		WithType(entity.FunctionTypeBM25))
+
+	indexOpts := make([]client.CreateIndexOption, 0)
+	// hnsw index for embedding field
+	indexOpts = append(indexOpts, client.NewCreateIndexOption(collectionName, fieldEmbedding, index.NewHNSWIndex(m.metricType, 16, 128)))
+	indexOpts = append(indexOpts, client.NewCreateIndexOption(collectionName, fieldContentSparse, index.NewAutoIndex(entity.BM25)))
+	// Create payload indexes for filtering
+	indexFields := []string{fieldChunkID, fieldKnowledgeID, fieldKnowledgeBaseID, fieldSourceID, fieldIsEnabled}
+	for _, fieldName := range indexFields {
+		indexOpts = append(indexOpts, client.NewCreateIndexOption(collectionName, fieldName, index.NewAutoIndex(entity.IP)))
 	}
-	loadTask, err := m.client.LoadCollection(ctx, loadOpt)
-	if err != nil {
-		log.Errorf("[Milvus] Failed to load collection: %v", err)
-		return fmt.Errorf("failed to load collection: %w", err)
+
+	// Create collection
+	createOpt := client.NewCreateCollectionOption(collectionName, schema).WithIndexOptions(indexOpts...)
+	if m.shardsNum > 0 {
+		createOpt = createOpt.WithShardNum(int32(m.shardsNum))
+	}
+	if m.loadPolicy == types.LoadPolicyMmap {
+		createOpt = createOpt.WithProperty(mmapEnabledKey, true)
 	}
-	if err := loadTask.Await(ctx); err != nil {
-		log.Errorf("[Milvus] Failed to await load collection: %v", err)
-		return fmt.Errorf("failed to await load collection: %w", err)
+	if err := m.client.CreateCollection(ctx, createOpt); err != nil {
+		log.Errorf("[Milvus] Failed to create collection: %v", err)
+		return fmt.Errorf("failed to create collection: %w", err)
 	}
 
-	// Mark as initialized
-	m.initializedCollections.Store(dimension, true)
+	log.Infof("[Milvus] Successfully created collection %s", collectionName)
 	return nil
 }
 
+// upsert writes rows, tracing the call and reporting its latency to
+// Prometheus.
+func (m *milvusRepository) upsert(ctx context.Context, opt client.UpsertOption) (client.UpsertResult, error) {
+	ctx, span := startSpan(ctx, "upsert", "")
+	start := time.Now()
+	result, err := m.client.Upsert(ctx, opt)
+	metrics.ObserveVectorStore(ctx, types.MilvusRetrieverEngineType, "upsert", start, err)
+	telemetry.End(span, err)
+	return result, err
+}
+
+// delete deletes rows, tracing the call and reporting its latency to
+// Prometheus.
+func (m *milvusRepository) delete(ctx context.Context, opt client.DeleteOption) (client.DeleteResult, error) {
+	ctx, span := startSpan(ctx, "delete", "")
+	start := time.Now()
+	result, err := m.client.Delete(ctx, opt)
+	metrics.ObserveVectorStore(ctx, types.MilvusRetrieverEngineType, "delete", start, err)
+	telemetry.End(span, err)
+	return result, err
+}
+
+// search runs an ANN or full-text search on collectionName in a span.
+func (m *milvusRepository) search(ctx context.Context, collectionName string,
+	opt client.SearchOption,
+) ([]client.ResultSet, error) {
+	ctx, span := startSpan(ctx, "search", collectionName)
+	resultSets, err := m.client.Search(ctx, opt)
+	telemetry.End(span, err)
+	return resultSets, err
+}
+
+// query runs a scalar query on collectionName in a span.
+func (m *milvusRepository) query(ctx context.Context, collectionName string,
+	opt client.QueryOption,
+) (client.ResultSet, error) {
+	ctx, span := startSpan(ctx, "query", collectionName)
+	resultSet, err := m.client.Query(ctx, opt)
+	telemetry.End(span, err)
+	return resultSet, err
+}
+
+// startSpan opens the OpenTelemetry span of a Milvus client call.
+func startSpan(ctx context.Context, operation, collectionName string) (context.Context, trace.Span) {
+	attrs := []attribute.KeyValue{
+		attribute.String("db.system.name", "milvus"),
+		attribute.String("db.operation.name", operation),
+	}
+	if collectionName != "" {
+		attrs = append(attrs, attribute.String("db.collection.name", collectionName))
+	}
+	return telemetry.Start(ctx, "milvus."+operation, attrs...)
+}
+
 func (m *milvusRepository) EngineType() types.RetrieverEngineType {
 	return types.MilvusRetrieverEngineType
 }
 
+// Support reports keyword retrieval only when content is stored in the
+// collection: the BM25 function has nothing to tokenize in reference mode.
 func (m *milvusRepository) Support() []types.RetrieverType {
+	if m.contentReference {
+		return []types.RetrieverType{types.VectorRetrieverType}
+	}
 	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
 }
 
+// Capabilities reports Milvus features. Partial updates need Milvus 2.6;
+// the flag drops once the server reports them unimplemented. Search TopK is capped
+// at 16384 by the server.
+func (m *milvusRepository) Capabilities() types.EngineCapabilities {
+	caps := types.NewEngineCapabilities(m.EngineType(), m.Support())
+	caps.FilterOperators = slices.Clone(filterOperators)
+	caps.MaxFilterDepth = maxFilterDepth
+	caps.PartialUpdate = !m.partialUpdateUnsupported.Load()
+	caps.MaxTopK = 16384
+	return caps
+}
+
 // EstimateStorageSize calculates the estimated storage size for a list of indices
 func (m *milvusRepository) EstimateStorageSize(ctx context.Context,
 	indexInfoList []*types.IndexInfo, params map[string]any,
 ) int64 {
 	var totalStorageSize int64
 	for _, embedding := range indexInfoList {
-		embeddingDB := toMilvusVectorEmbedding(embedding, params)
+		embeddingDB := m.toStoredEmbedding(embedding, params)
 		totalStorageSize += m.calculateStorageSize(embeddingDB)
 	}
 	logger.GetLogger(ctx).Infof(
@@ -238,7 +358,7 @@ func (m *milvusRepository) Save(ctx context.Context,
 	log := logger.GetLogger(ctx)
 	log.Debugf("[Milvus] Saving index for chunk ID: %s", embedding.ChunkID)
 
-	embeddingDB := toMilvusVectorEmbedding(embedding, additionalParams)
+	embeddingDB := m.toStoredEmbedding(embedding, additionalParams)
 	if len(embeddingDB.Embedding) == 0 {
 		err := fmt.Errorf("empty embedding vector for chunk ID: %s", embedding.ChunkID)
 		log.Errorf("[Milvus] %v", err)
@@ -246,16 +366,16 @@ func (m *milvusRepository) Save(ctx context.Context,
 	}
 
 	dimension := len(embeddingDB.Embedding)
-	if err := m.ensureCollection(ctx, dimension); err != nil {
-		return err
-	}
-
-	collectionName := m.getCollectionName(dimension)
+	collectionName := m.getModelCollectionName(embedding.EmbeddingModelID, dimension)
 
-	embeddingDB.ID = uuid.New().String()
+	// A deterministic key makes a retried Save overwrite, not duplicate.
+	embeddingDB.ID = embedding.RowID()
 	opts := createUpsert(collectionName, []*MilvusVectorEmbedding{embeddingDB})
 
-	_, err := m.client.Upsert(ctx, opts)
+	err := m.writeCollection(ctx, collectionName, dimension, func() error {
+		_, err := m.upsert(ctx, opts)
+		return err
+	})
 	if err != nil {
 		log.Errorf("[Milvus] Failed to save index: %v", err)
 		return err
@@ -277,8 +397,9 @@ func (m *milvusRepository) BatchSave(ctx context.Context,
 
 	log.Infof("[Milvus] Batch saving %d indices", len(embeddingList))
 
-	// Group points by dimension
-	embeddingsByDimension := make(map[int][]*types.IndexInfo)
+	// Group points by target collection (embedding model + dimension)
+	embeddingsByCollection := make(map[string][]*types.IndexInfo)
+	dimensionByCollection := make(map[string]int)
 
 	for _, embedding := range embeddingList {
 		embeddingDB := toMilvusVectorEmbedding(embedding, additionalParams)
@@ -288,43 +409,99 @@ func (m *milvusRepository) BatchSave(ctx context.Context,
 		}
 
 		dimension := len(embeddingDB.Embedding)
-		embeddingsByDimension[dimension] = append(embeddingsByDimension[dimension], embedding)
-		log.Debugf("[Milvus] Added chunk ID %s to batch request (dimension: %d)", embedding.ChunkID, dimension)
+		collectionName := m.getModelCollectionName(embedding.EmbeddingModelID, dimension)
+		embeddingsByCollection[collectionName] = append(embeddingsByCollection[collectionName], embedding)
+		dimensionByCollection[collectionName] = dimension
+		log.Debugf("[Milvus] Added chunk ID %s to batch request (collection: %s)", embedding.ChunkID, collectionName)
 	}
 
-	if len(embeddingsByDimension) == 0 {
+	if len(embeddingsByCollection) == 0 {
 		log.Warn("[Milvus] No valid points to save after filtering")
 		return nil
 	}
 
-	// Save points to each dimension-specific collection
-	totalSaved := 0
-	for dimension, embeddings := range embeddingsByDimension {
-		if err := m.ensureCollection(ctx, dimension); err != nil {
-			return err
-		}
+	// Save points to the model-specific collections concurrently. A failing
+	// collection does not stop the others; all failures are reported.
+	var totalSaved atomic.Int64
+	err := utils.ForEachLimit(slices.Sorted(maps.Keys(embeddingsByCollection)), collectionConcurrency,
+		func(_ int, collectionName string) error {
+			n, err := m.upsertCollection(ctx, collectionName, dimensionByCollection[collectionName],
+				embeddingsByCollection[collectionName], additionalParams)
+			if err != nil {
+				return err
+			}
+			totalSaved.Add(int64(n))
+			return nil
+		})
+	if err != nil {
+		return err
+	}
 
-		collectionName := m.getCollectionName(dimension)
-		n := len(embeddings)
-		embeddingDBList := make([]*MilvusVectorEmbedding, 0, n)
+	log.Infof("[Milvus] Successfully batch saved %d indices", totalSaved.Load())
+	return nil
+}
 
-		for _, embedding := range embeddings {
-			embeddingDB := toMilvusVectorEmbedding(embedding, additionalParams)
-			embeddingDB.ID = uuid.New().String()
-			embeddingDBList = append(embeddingDBList, embeddingDB)
-		}
-		opts := createUpsert(collectionName, embeddingDBList)
-		_, err := m.client.Upsert(ctx, opts)
-		if err != nil {
-			log.Errorf("[Milvus] Failed to execute batch operation for dimension %d: %v", dimension, err)
-			return fmt.Errorf("failed to batch save (dimension %d): %w", dimension, err)
+// upsertCollection writes embeddings to one collection, creating it first
+// if needed, and returns the number of rows written.
+func (m *milvusRepository) upsertCollection(ctx context.Context, collectionName string, dimension int,
+	embeddings []*types.IndexInfo, additionalParams map[string]any,
+) (int, error) {
+	log := logger.GetLogger(ctx)
+	embeddingDBList := make([]*MilvusVectorEmbedding, 0, len(embeddings))
+	positionByID := make(map[string]int, len(embeddings))
+
+	for _, embedding := range embeddings {
+		embeddingDB := m.toStoredEmbedding(embedding, additionalParams)
+		embeddingDB.ID = embedding.RowID()
+		// Upsert rejects repeated primary keys within one request; the
+		// last occurrence wins, as it would across two requests.
+		if pos, ok := positionByID[embeddingDB.ID]; ok {
+			embeddingDBList[pos] = embeddingDB
+			continue
 		}
-		totalSaved += n
-		log.Infof("[Milvus] Saved %d points to collection %s", n, collectionName)
+		positionByID[embeddingDB.ID] = len(embeddingDBList)
+		embeddingDBList = append(embeddingDBList, embeddingDB)
+	}
+	n := len(embeddingDBList)
+	opts := createUpsert(collectionName, embeddingDBList)
+	err := m.writeCollection(ctx, collectionName, dimension, func() error {
+		_, err := m.upsert(ctx, opts)
+		return err
+	})
+	if err != nil {
+		log.Errorf("[Milvus] Failed to execute batch operation for collection %s: %v", collectionName, err)
+		return 0, fmt.Errorf("failed to batch save (collection %s): %w", collectionName, err)
 	}
+	log.Infof("[Milvus] Saved %d points to collection %s", n, collectionName)
+	return n, nil
+}
 
-	log.Infof("[Milvus] Successfully batch saved %d indices", totalSaved)
-	return nil
+// writeCollection runs write against collectionName, creating the
+// collection first if needed, while this process cannot drop it as empty.
+// A collection that another instance dropped after this one cached it is
+// recreated and the write retried once.
+func (m *milvusRepository) writeCollection(ctx context.Context,
+	collectionName string, dimension int, write func() error,
+) error {
+	return m.dropGuard.Write(collectionName, func() error {
+		if err := m.ensureCollection(ctx, collectionName, dimension); err != nil {
+			return err
+		}
+		err := write()
+		if err == nil {
+			return nil
+		}
+		exists, hasErr := m.client.HasCollection(ctx, client.NewHasCollectionOption(collectionName))
+		if hasErr != nil || exists {
+			return err
+		}
+		logger.GetLogger(ctx).Warnf("[Milvus] Collection %s was dropped elsewhere, recreating it", collectionName)
+		m.forgetCollection(collectionName)
+		if err := m.ensureCollection(ctx, collectionName, dimension); err != nil {
+			return err
+		}
+		return write()
+	})
 }
 
 // DeleteByChunkIDList removes points from the collection based on chunk IDs
@@ -335,15 +512,20 @@ func (m *milvusRepository) DeleteByChunkIDList(ctx context.Context, chunkIDList
 		return nil
 	}
 
-	collectionName := m.getCollectionName(dimension)
-	log.Infof("[Milvus] Deleting indices by chunk IDs from %s, count: %d", collectionName, len(chunkIDList))
-
-	deleteOpt := client.NewDeleteOption(collectionName)
-	deleteOpt.WithStringIDs(fieldChunkID, chunkIDList)
-	_, err := m.client.Delete(ctx, deleteOpt)
+	// Rows of one dimension may live in several per-model collections.
+	collections, err := m.collectionsForDimension(ctx, dimension)
 	if err != nil {
-		log.Errorf("[Milvus] Failed to delete by chunk IDs: %v", err)
-		return fmt.Errorf("failed to delete by chunk IDs: %w", err)
+		return err
+	}
+	for _, collectionName := range collections {
+		log.Infof("[Milvus] Deleting indices by chunk IDs from %s, count: %d", collectionName, len(chunkIDList))
+
+		deleteOpt := client.NewDeleteOption(collectionName)
+		deleteOpt.WithStringIDs(fieldChunkID, chunkIDList)
+		if _, err := m.delete(ctx, deleteOpt); err != nil {
+			log.Errorf("[Milvus] Failed to delete by chunk IDs: %v", err)
+			return fmt.Errorf("failed to delete by chunk IDs: %w", err)
+		}
 	}
 
 	log.Infof("[Milvus] Successfully deleted documents by chunk IDs")
@@ -360,15 +542,20 @@ func (m *milvusRepository) DeleteByKnowledgeIDList(ctx context.Context,
 		return nil
 	}
 
-	collectionName := m.getCollectionName(dimension)
-	log.Infof("[Milvus] Deleting indices by knowledge IDs from %s, count: %d", collectionName, len(knowledgeIDList))
-
-	deleteOpt := client.NewDeleteOption(collectionName)
-	deleteOpt.WithStringIDs(fieldKnowledgeID, knowledgeIDList)
-	_, err := m.client.Delete(ctx, deleteOpt)
+	// Rows of one dimension may live in several per-model collections.
+	collections, err := m.collectionsForDimension(ctx, dimension)
 	if err != nil {
-		log.Errorf("[Milvus] Failed to delete by knowledge IDs: %v", err)
-		return fmt.Errorf("failed to delete by knowledge IDs: %w", err)
+		return err
+	}
+	for _, collectionName := range collections {
+		log.Infof("[Milvus] Deleting indices by knowledge IDs from %s, count: %d", collectionName, len(knowledgeIDList))
+
+		deleteOpt := client.NewDeleteOption(collectionName)
+		deleteOpt.WithStringIDs(fieldKnowledgeID, knowledgeIDList)
+		if _, err := m.delete(ctx, deleteOpt); err != nil {
+			log.Errorf("[Milvus] Failed to delete by knowledge IDs: %v", err)
+			return fmt.Errorf("failed to delete by knowledge IDs: %w", err)
+		}
 	}
 
 	log.Infof("[Milvus] Successfully deleted documents by knowledge IDs")
@@ -385,21 +572,111 @@ func (m *milvusRepository) DeleteBySourceIDList(ctx context.Context,
 		return nil
 	}
 
-	collectionName := m.getCollectionName(dimension)
-	log.Infof("[Milvus] Deleting indices by source IDs from %s, count: %d", collectionName, len(sourceIDList))
-
-	deleteOpt := client.NewDeleteOption(collectionName)
-	deleteOpt.WithStringIDs(fieldSourceID, sourceIDList)
-	_, err := m.client.Delete(ctx, deleteOpt)
+	// Rows of one dimension may live in several per-model collections.
+	collections, err := m.collectionsForDimension(ctx, dimension)
 	if err != nil {
-		log.Errorf("[Milvus] Failed to delete by source IDs: %v", err)
-		return fmt.Errorf("failed to delete by source IDs: %w", err)
+		return err
+	}
+	for _, collectionName := range collections {
+		log.Infof("[Milvus] Deleting indices by source IDs from %s, count: %d", collectionName, len(sourceIDList))
+
+		deleteOpt := client.NewDeleteOption(collectionName)
+		deleteOpt.WithStringIDs(fieldSourceID, sourceIDList)
+		if _, err := m.delete(ctx, deleteOpt); err != nil {
+			log.Errorf("[Milvus] Failed to delete by source IDs: %v", err)
+			return fmt.Errorf("failed to delete by source IDs: %w", err)
+		}
 	}
 
 	log.Infof("[Milvus] Successfully deleted documents by source IDs")
 	return nil
 }
 
+// DeleteByKnowledgeBaseIDList removes every point of the given knowledge
+// bases and drops collections of the dimension left empty
+func (m *milvusRepository) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	if len(knowledgeBaseIDList) == 0 {
+		logger.GetLogger(ctx).Warn("[Milvus] Empty knowledge base ID list provided for deletion, skipping")
+		return nil
+	}
+	return m.deleteByField(ctx, fieldKnowledgeBaseID, knowledgeBaseIDList, dimension)
+}
+
+// DeleteByTagIDList removes points based on tag IDs and drops collections of
+// the dimension left empty
+func (m *milvusRepository) DeleteByTagIDList(ctx context.Context,
+	tagIDList []string, dimension int, knowledgeType string,
+) error {
+	if len(tagIDList) == 0 {
+		logger.GetLogger(ctx).Warn("[Milvus] Empty tag ID list provided for deletion, skipping")
+		return nil
+	}
+	return m.deleteByField(ctx, fieldTagID, tagIDList, dimension)
+}
+
+// deleteByField deletes the rows whose field matches one of values from
+// every collection of the dimension, then drops the collections that end up
+// empty so tearing down the last knowledge base of a model space does not
+// leave an empty collection (and its loaded index) behind.
+func (m *milvusRepository) deleteByField(ctx context.Context,
+	field string, values []string, dimension int,
+) error {
+	log := logger.GetLogger(ctx)
+	collections, err := m.collectionsForDimension(ctx, dimension)
+	if err != nil {
+		return err
+	}
+	for _, collectionName := range collections {
+		log.Infof("[Milvus] Deleting indices by %s from %s, count: %d", field, collectionName, len(values))
+
+		deleteOpt := client.NewDeleteOption(collectionName)
+		deleteOpt.WithStringIDs(field, values)
+		if _, err := m.delete(ctx, deleteOpt); err != nil {
+			log.Errorf("[Milvus] Failed to delete by %s: %v", field, err)
+			return fmt.Errorf("failed to delete by %s: %w", field, err)
+		}
+		m.dropIfEmpty(ctx, collectionName, dimension)
+	}
+
+	log.Infof("[Milvus] Successfully deleted documents by %s", field)
+	return nil
+}
+
+// dropIfEmpty drops collectionName if it holds no rows. The rows are counted
+// again with this process's writes to the collection held off, and the
+// collection is evicted from the caches before they resume, so a concurrent
+// write either lands first and keeps the collection or recreates it. The
+// delete already succeeded; failures here only skip the cleanup, and a
+// count still including not-yet-compacted deletes keeps the collection.
+func (m *milvusRepository) dropIfEmpty(ctx context.Context, collectionName string, dimension int) {
+	log := logger.GetLogger(ctx)
+	dropped, err := m.dropGuard.DropIfEmpty(collectionName,
+		func() (int, error) {
+			// count(*) needs the collection loaded; ensureCollection is a
+			// cache hit for collections this process already served.
+			if err := m.ensureCollection(ctx, collectionName, dimension); err != nil {
+				return 0, err
+			}
+			return m.countRows(ctx, collectionName)
+		},
+		func() error {
+			if err := m.dropCollectionIfExists(ctx, collectionName); err != nil {
+				return err
+			}
+			m.forgetCollection(collectionName)
+			return nil
+		})
+	if err != nil {
+		log.Warnf("[Milvus] Skipping empty collection cleanup of %s: %v", collectionName, err)
+		return
+	}
+	if dropped {
+		log.Infof("[Milvus] Dropped empty collection %s", collectionName)
+	}
+}
+
 // BatchUpdateChunkEnabledStatus updates the enabled status of chunks in batch
 func (m *milvusRepository) BatchUpdateChunkEnabledStatus(ctx context.Context, chunkStatusMap map[string]bool) error {
 	log := logger.GetLogger(ctx)
@@ -429,35 +706,156 @@ func (m *milvusRepository) BatchUpdateChunkEnabledStatus(ctx context.Context, ch
 		}
 	}
 
-	// Update in all matching collections
-	for _, collectionName := range collections {
-		// Only process collections that start with our base name
-		if len(collectionName) <= len(m.collectionBaseName) ||
-			collectionName[:len(m.collectionBaseName)] != m.collectionBaseName {
-			continue
-		}
+	// Update in all matching collections concurrently
+	_ = utils.ForEachLimit(m.ownCollections(collections), collectionConcurrency, func(_ int, collectionName string) error {
 		if err := m.updateChunkEnabledStatusInCollection(ctx, collectionName, enabledChunkIDs, true); err != nil {
 			log.Warnf("[Milvus] Failed to update enabled chunks in %s: %v", collectionName, err)
 		}
 		if err := m.updateChunkEnabledStatusInCollection(ctx, collectionName, disabledChunkIDs, false); err != nil {
 			log.Warnf("[Milvus] Failed to update disabled chunks in %s: %v", collectionName, err)
 		}
-	}
+		return nil
+	})
 
 	log.Infof("[Milvus] Batch update chunk enabled status completed")
 	return nil
 }
 
+// ownCollections keeps the collections named after this repository's base
+// name, i.e. the ones holding its embeddings.
+func (m *milvusRepository) ownCollections(collections []string) []string {
+	own := make([]string, 0, len(collections))
+	for _, collectionName := range collections {
+		if len(collectionName) > len(m.collectionBaseName) && strings.HasPrefix(collectionName, m.collectionBaseName) {
+			own = append(own, collectionName)
+		}
+	}
+	return own
+}
+
+// updateChunkEnabledStatusInCollection sets is_enabled on the rows of
+// chunkIDs in one collection, enabledStatusBatchSize chunks at a time. It
+// prefers a partial upsert that sends only the primary keys and the
+// is_enabled column (Milvus 2.6+), so vectors are neither read back nor
+// rewritten and concurrent writes to other fields are not clobbered. When a
+// partial upsert fails, the whole rows are read and upserted instead; once
+// the server says it does not implement partial updates, later calls go
+// straight to that path.
 func (m *milvusRepository) updateChunkEnabledStatusInCollection(
 	ctx context.Context,
 	collectionName string,
 	chunkIDs []string,
 	enabled bool,
 ) error {
-	if len(chunkIDs) == 0 {
+	return m.setEnabledStatus(ctx, chunkIDs,
+		func(batch []string) error {
+			return m.partialUpdateChunkEnabledStatus(ctx, collectionName, batch, enabled)
+		},
+		func(batch []string) error {
+			return m.upsertChunkEnabledStatus(ctx, collectionName, batch, enabled)
+		})
+}
+
+// setEnabledStatus runs the enabled-status update of chunkIDs in batches,
+// through partial, or fullRow when partial fails or is unsupported.
+func (m *milvusRepository) setEnabledStatus(
+	ctx context.Context, chunkIDs []string, partial, fullRow func(batch []string) error,
+) error {
+	for batch := range slices.Chunk(chunkIDs, enabledStatusBatchSize) {
+		if m.partialUpdateUnsupported.Load() {
+			if err := fullRow(batch); err != nil {
+				return err
+			}
+			continue
+		}
+		partialErr := partial(batch)
+		if partialErr == nil {
+			continue
+		}
+		if isUnsupportedFeature(partialErr) {
+			m.partialUpdateUnsupported.Store(true)
+			logger.Warnf(ctx, "[Milvus] Partial updates are not supported, using full-row upserts for enabled status: %v", partialErr)
+		} else {
+			logger.Warnf(ctx, "[Milvus] Partial update of enabled status failed, retrying with a full-row upsert: %v", partialErr)
+		}
+		if err := fullRow(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isUnsupportedFeature reports whether err says the server does not
+// implement a request, as opposed to failing it: a gRPC Unimplemented
+// status or Milvus' service unimplemented error code.
+func isUnsupportedFeature(err error) bool {
+	var coded interface{ Code() int32 }
+	if errors.As(err, &coded) && coded.Code() == milvusCodeServiceUnimplemented {
+		return true
+	}
+	st, ok := status.FromError(err)
+	return ok && st.Code() == codes.Unimplemented
+}
+
+// partialUpdateChunkEnabledStatus looks up the primary keys of the rows of
+// chunkIDs and upserts only their is_enabled column.
+func (m *milvusRepository) partialUpdateChunkEnabledStatus(
+	ctx context.Context,
+	collectionName string,
+	chunkIDs []string,
+	enabled bool,
+) error {
+	params, err := m.filter.Convert(&universalFilterCondition{
+		Field:    fieldChunkID,
+		Operator: operatorIn,
+		Value:    chunkIDs,
+	})
+	if err != nil {
+		return err
+	}
+	queryOpt := client.NewQueryOption(collectionName)
+	queryOpt.WithFilter(params.exprStr)
+	for k, v := range params.params {
+		queryOpt.WithTemplateParam(k, v)
+	}
+	queryOpt.WithOutputFields(fieldID)
+	if err := m.awaitLoad(ctx, collectionName); err != nil {
+		return err
+	}
+	resultSet, err := m.query(ctx, collectionName, queryOpt)
+	if err != nil {
+		return err
+	}
+	col := resultSet.GetColumn(fieldID)
+	if col == nil || col.Len() == 0 {
 		return nil
 	}
+	ids := make([]string, 0, col.Len())
+	for i := 0; i < col.Len(); i++ {
+		id, err := col.GetAsString(i)
+		if err != nil {
+			return err
+		}
+		ids = append(ids, id)
+	}
 
+	opt := client.NewColumnBasedInsertOption(collectionName).
+		WithVarcharColumn(fieldID, ids).
+		WithBoolColumn(fieldIsEnabled, slices.Repeat([]bool{enabled}, len(ids))).
+		WithPartialUpdate(true)
+	_, err = m.upsert(ctx, opt)
+	return err
+}
+
+// upsertChunkEnabledStatus is the read-modify-write fallback for servers
+// without partial update support: it reads the full rows, vectors
+// included, and upserts them with the new is_enabled value.
+func (m *milvusRepository) upsertChunkEnabledStatus(
+	ctx context.Context,
+	collectionName string,
+	chunkIDs []string,
+	enabled bool,
+) error {
 	embeddings, _, err := m.searchByFilter(ctx, collectionName, &universalFilterCondition{
 		Field:    fieldChunkID,
 		Operator: operatorIn,
@@ -477,7 +875,7 @@ func (m *milvusRepository) updateChunkEnabledStatusInCollection(
 	}
 
 	req := createUpsert(collectionName, upsertEmbeddings)
-	if _, err := m.client.Upsert(ctx, req); err != nil {
+	if _, err := m.upsert(ctx, req); err != nil {
 		return err
 	}
 	return nil
@@ -502,7 +900,10 @@ func (m *milvusRepository) searchByFilter(ctx context.Context, collectionName st
 	if offset != nil {
 		queryOpt.WithOffset(*offset)
 	}
-	resultSet, err := m.client.Query(ctx, queryOpt)
+	if err := m.awaitLoad(ctx, collectionName); err != nil {
+		return nil, 0, err
+	}
+	resultSet, err := m.query(ctx, collectionName, queryOpt)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -561,7 +962,7 @@ func (m *milvusRepository) BatchUpdateChunkTagID(ctx context.Context, chunkTagMa
 			}
 			if len(upsertEmbeddings) > 0 {
 				req := createUpsert(collectionName, upsertEmbeddings)
-				_, err := m.client.Upsert(ctx, req)
+				_, err := m.upsert(ctx, req)
 				if err != nil {
 					log.Warnf("[Milvus] Failed to update chunks in %s: %v", collectionName, err)
 					continue
@@ -598,18 +999,18 @@ func (m *milvusRepository) getBaseFilterForQuery(params types.RetrieveParams) (s
 			Value:    params.TagIDs,
 		})
 	}
-	if len(params.ExcludeKnowledgeIDs) > 0 {
+	if excluded := params.ExcludedKnowledgeIDs(); len(excluded) > 0 {
 		filters = append(filters, &universalFilterCondition{
 			Field:    fieldKnowledgeID,
 			Operator: operatorNotIn,
-			Value:    params.ExcludeKnowledgeIDs,
+			Value:    excluded,
 		})
 	}
-	if len(params.ExcludeChunkIDs) > 0 {
+	if excludedChunks := params.ExcludedChunkIDs(); len(excludedChunks) > 0 {
 		filters = append(filters, &universalFilterCondition{
 			Field:    fieldChunkID,
 			Operator: operatorNotIn,
-			Value:    params.ExcludeChunkIDs,
+			Value:    excludedChunks,
 		})
 	}
 	filters = append(filters, &universalFilterCondition{
@@ -649,29 +1050,69 @@ func (m *milvusRepository) Retrieve(ctx context.Context,
 	return nil, err
 }
 
-// VectorRetrieve performs vector similarity search
+// VectorRetrieve performs vector similarity search. Rows written before
+// per-model collections existed stay in the legacy per-dimension collection
+// until MigrateModelSpaces moves them, so both are searched and merged.
 func (m *milvusRepository) VectorRetrieve(ctx context.Context,
 	params types.RetrieveParams,
 ) ([]*types.RetrieveResult, error) {
 	log := logger.GetLogger(ctx)
 	dimension := len(params.Embedding)
-	log.Infof("[Milvus] Vector retrieval: dim=%d, topK=%d, threshold=%.4f",
-		dimension, params.TopK, params.Threshold)
+	log.Infof("[Milvus] Vector retrieval: dim=%d, model=%s, topK=%d, threshold=%.4f",
+		dimension, params.EmbeddingModelID, params.TopK, params.Threshold)
 
-	// Get collection name based on embedding dimension
-	collectionName := m.getCollectionName(dimension)
+	collectionNames := []string{m.getCollectionName(dimension)}
+	if params.EmbeddingModelID != "" {
+		collectionNames = append([]string{m.getModelCollectionName(params.EmbeddingModelID, dimension)}, collectionNames...)
+	}
 
-	// Check if collection exists
-	hasCollection, err := m.client.HasCollection(ctx, client.NewHasCollectionOption(collectionName))
-	if err != nil {
-		log.Errorf("[Milvus] Failed to check collection existence: %v", err)
-		return nil, fmt.Errorf("failed to check collection: %w", err)
+	var results []*types.IndexWithScore
+	for _, collectionName := range collectionNames {
+		// Check if collection exists
+		hasCollection, err := m.client.HasCollection(ctx, client.NewHasCollectionOption(collectionName))
+		if err != nil {
+			log.Errorf("[Milvus] Failed to check collection existence: %v", err)
+			return nil, fmt.Errorf("failed to check collection: %w", err)
+		}
+		if !hasCollection {
+			log.Debugf("[Milvus] Collection %s does not exist, skipping", collectionName)
+			continue
+		}
+		collectionResults, err := m.vectorSearchCollection(ctx, collectionName, params)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, collectionResults...)
 	}
-	if !hasCollection {
-		log.Warnf("[Milvus] Collection %s does not exist, returning empty results", collectionName)
-		return buildRetrieveResult(nil, types.VectorRetrieverType), nil
+
+	if len(collectionNames) > 1 {
+		// L2 is a distance (lower is closer); IP and COSINE are similarities.
+		slices.SortStableFunc(results, func(a, b *types.IndexWithScore) int {
+			if m.metricType == entity.L2 {
+				return cmp.Compare(a.Score, b.Score)
+			}
+			return cmp.Compare(b.Score, a.Score)
+		})
+		if len(results) > params.TopK {
+			results = results[:params.TopK]
+		}
 	}
 
+	if len(results) == 0 {
+		log.Warnf("[Milvus] No vector matches found that meet threshold %.4f", params.Threshold)
+	} else {
+		log.Infof("[Milvus] Vector retrieval found %d results", len(results))
+		log.Debugf("[Milvus] Top result score: %.4f", results[0].Score)
+	}
+	return buildRetrieveResult(results, types.VectorRetrieverType), nil
+}
+
+// vectorSearchCollection runs the ANN search of VectorRetrieve against a
+// single collection.
+func (m *milvusRepository) vectorSearchCollection(ctx context.Context,
+	collectionName string, params types.RetrieveParams,
+) ([]*types.IndexWithScore, error) {
+	log := logger.GetLogger(ctx)
 	expr, paramsMap, err := m.getBaseFilterForQuery(params)
 	if err != nil {
 		log.Errorf("[Milvus] Failed to build base filter: %v", err)
@@ -695,7 +1136,11 @@ func (m *milvusRepository) VectorRetrieve(ctx context.Context,
 		}
 	}
 	searchOption.WithOutputFields("*")
-	resultSet, err := m.client.Search(ctx, searchOption)
+	if err := m.awaitLoad(ctx, collectionName); err != nil {
+		log.Errorf("[Milvus] Collection %s is not loaded: %v", collectionName, err)
+		return nil, err
+	}
+	resultSet, err := m.search(ctx, collectionName, searchOption)
 	if err != nil {
 		log.Errorf("[Milvus] Vector search failed: %v", err)
 		return nil, fmt.Errorf("failed to search: %w", err)
@@ -705,18 +1150,12 @@ func (m *milvusRepository) VectorRetrieve(ctx context.Context,
 		log.Errorf("[Milvus] Failed to convert result set: %v", err)
 		return nil, fmt.Errorf("failed to convert result set: %w", err)
 	}
-	var results []*types.IndexWithScore
+	results := make([]*types.IndexWithScore, 0, len(sets))
 	for i, set := range sets {
 		set.Score = scores[i]
 		results = append(results, fromMilvusVectorEmbedding(set.ID, set, types.MatchTypeEmbedding))
 	}
-	if len(results) == 0 {
-		log.Warnf("[Milvus] No vector matches found that meet threshold %.4f", params.Threshold)
-	} else {
-		log.Infof("[Milvus] Vector retrieval found %d results", len(results))
-		log.Debugf("[Milvus] Top result score: %.4f", results[0].Score)
-	}
-	return buildRetrieveResult(results, types.VectorRetrieverType), nil
+	return results, nil
 }
 
 // KeywordsRetrieve performs keyword-based search in document content
@@ -726,6 +1165,12 @@ func (m *milvusRepository) KeywordsRetrieve(ctx context.Context,
 	log := logger.GetLogger(ctx)
 	log.Infof("[Milvus] Performing keywords retrieval with query: %s, topK: %d", params.Query, params.TopK)
 
+	if m.contentReference {
+		// Support() already hides keyword retrieval; this guards direct callers.
+		log.Warn("[Milvus] Keywords retrieval skipped: content reference mode stores no content")
+		return buildRetrieveResult(nil, types.KeywordsRetrieverType), nil
+	}
+
 	// Get all collections
 	collections, err := m.client.ListCollections(ctx, client.NewListCollectionOption())
 	if err != nil {
@@ -733,44 +1178,17 @@ func (m *milvusRepository) KeywordsRetrieve(ctx context.Context,
 		return nil, fmt.Errorf("failed to list collections: %w", err)
 	}
 
+	// Search all matching collections concurrently; each call owns its slot
+	// so results keep the collection order.
+	collections = m.ownCollections(collections)
+	resultsByCollection := make([][]*types.IndexWithScore, len(collections))
+	_ = utils.ForEachLimit(collections, collectionConcurrency, func(i int, collectionName string) error {
+		resultsByCollection[i] = m.keywordsSearchCollection(ctx, collectionName, params)
+		return nil
+	})
 	var allResults []*types.IndexWithScore
-
-	// Search in all matching collections
-	for _, collectionName := range collections {
-		// Only process collections that start with our base name
-		if len(collectionName) <= len(m.collectionBaseName) ||
-			collectionName[:len(m.collectionBaseName)] != m.collectionBaseName {
-			continue
-		}
-
-		expr, paramsMap, err := m.getBaseFilterForQuery(params)
-		if err != nil {
-			log.Errorf("[Milvus] Failed to build base filter: %v", err)
-			continue
-		}
-		searchOpt := client.NewSearchOption(collectionName, params.TopK, []entity.Vector{entity.Text(params.Query)})
-		searchOpt.WithANNSField(fieldContentSparse)
-		if expr != "" {
-			searchOpt.WithFilter(expr)
-			for k, v := range paramsMap {
-				searchOpt.WithTemplateParam(k, v)
-			}
-		}
-		searchOpt.WithOutputFields("*")
-		resultSet, err := m.client.Search(ctx, searchOpt)
-		if err != nil {
-			log.Errorf("[Milvus] Keywords search failed: %v", err)
-			continue
-		}
-		sets, _, err := convertResultSet(resultSet)
-		if err != nil {
-			log.Errorf("[Milvus] Failed to convert result set: %v", err)
-			continue
-		}
-		for _, set := range sets {
-			set.Score = 1.0
-			allResults = append(allResults, fromMilvusVectorEmbedding(set.ID, set, types.MatchTypeKeywords))
-		}
+	for _, results := range resultsByCollection {
+		allResults = append(allResults, results...)
 	}
 
 	// Limit results to topK
@@ -787,6 +1205,48 @@ func (m *milvusRepository) KeywordsRetrieve(ctx context.Context,
 	return buildRetrieveResult(allResults, types.KeywordsRetrieverType), nil
 }
 
+// keywordsSearchCollection runs the BM25 search in one collection. A
+// failing collection is logged and contributes no results.
+func (m *milvusRepository) keywordsSearchCollection(ctx context.Context,
+	collectionName string, params types.RetrieveParams,
+) []*types.IndexWithScore {
+	log := logger.GetLogger(ctx)
+	expr, paramsMap, err := m.getBaseFilterForQuery(params)
+	if err != nil {
+		log.Errorf("[Milvus] Failed to build base filter: %v", err)
+		return nil
+	}
+	searchOpt := client.NewSearchOption(collectionName, params.TopK, []entity.Vector{entity.Text(params.Query)})
+	searchOpt.WithANNSField(fieldContentSparse)
+	if expr != "" {
+		searchOpt.WithFilter(expr)
+		for k, v := range paramsMap {
+			searchOpt.WithTemplateParam(k, v)
+		}
+	}
+	searchOpt.WithOutputFields("*")
+	if err := m.awaitLoad(ctx, collectionName); err != nil {
+		log.Errorf("[Milvus] Collection %s is not loaded: %v", collectionName, err)
+		return nil
+	}
+	resultSet, err := m.search(ctx, collectionName, searchOpt)
+	if err != nil {
+		log.Errorf("[Milvus] Keywords search failed in %s: %v", collectionName, err)
+		return nil
+	}
+	sets, _, err := convertResultSet(resultSet)
+	if err != nil {
+		log.Errorf("[Milvus] Failed to convert result set: %v", err)
+		return nil
+	}
+	results := make([]*types.IndexWithScore, 0, len(sets))
+	for _, set := range sets {
+		set.Score = 1.0
+		results = append(results, fromMilvusVectorEmbedding(set.ID, set, types.MatchTypeKeywords))
+	}
+	return results
+}
+
 // CopyIndices copies index data from source knowledge base to target knowledge base
 func (m *milvusRepository) CopyIndices(ctx context.Context,
 	sourceKnowledgeBaseID string,
@@ -807,11 +1267,40 @@ func (m *milvusRepository) CopyIndices(ctx context.Context,
 		return nil
 	}
 
-	collectionName := m.getCollectionName(dimension)
-	// Ensure target collection exists
-	if err := m.ensureCollection(ctx, dimension); err != nil {
+	// The source rows may sit in the legacy collection or in any per-model
+	// collection of this dimension; copies stay next to their source rows.
+	collections, err := m.collectionsForDimension(ctx, dimension)
+	if err != nil {
 		return err
 	}
+	totalCopied := 0
+	for _, collectionName := range collections {
+		copied, err := m.copyIndicesInCollection(ctx, collectionName, dimension,
+			sourceKnowledgeBaseID, sourceToTargetKBIDMap, sourceToTargetChunkIDMap, targetKnowledgeBaseID)
+		totalCopied += copied
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Infof("[Milvus] Index copy completed, total copied: %d", totalCopied)
+	return nil
+}
+
+// copyIndicesInCollection copies the source knowledge base's rows of one
+// collection back into the same collection under the target IDs.
+func (m *milvusRepository) copyIndicesInCollection(ctx context.Context,
+	collectionName string,
+	dimension int,
+	sourceKnowledgeBaseID string,
+	sourceToTargetKBIDMap map[string]string,
+	sourceToTargetChunkIDMap map[string]string,
+	targetKnowledgeBaseID string,
+) (int, error) {
+	log := logger.GetLogger(ctx)
+	if err := m.ensureCollection(ctx, collectionName, dimension); err != nil {
+		return 0, err
+	}
 
 	batchSize := 64
 	totalCopied := 0
@@ -824,7 +1313,7 @@ func (m *milvusRepository) CopyIndices(ctx context.Context,
 		}, &batchSize, offset)
 		if err != nil {
 			log.Errorf("[Milvus] Failed to query source points: %v", err)
-			return err
+			return totalCopied, err
 		}
 		if len(sourceEmbeddings) == 0 {
 			break
@@ -855,7 +1344,7 @@ func (m *milvusRepository) CopyIndices(ctx context.Context,
 				targetSourceID = uuid.New().String()
 			}
 			targetEmbedding := &MilvusVectorEmbedding{
-				ID:              uuid.New().String(),
+				ID:              types.IndexRowID(targetChunkID, targetSourceID, types.SourceType(sourceEmbedding.SourceType)),
 				Content:         sourceEmbedding.Content,
 				SourceID:        targetSourceID,
 				SourceType:      sourceEmbedding.SourceType,
@@ -870,10 +1359,10 @@ func (m *milvusRepository) CopyIndices(ctx context.Context,
 		}
 		if len(targetEmbeddings) > 0 {
 			opts := createUpsert(collectionName, targetEmbeddings)
-			_, err := m.client.Upsert(ctx, opts)
+			_, err := m.upsert(ctx, opts)
 			if err != nil {
 				log.Errorf("[Milvus] Failed to batch upsert target points: %v", err)
-				return err
+				return totalCopied, err
 			}
 			totalCopied += len(targetEmbeddings)
 			log.Infof("[Milvus] Successfully copied batch, batch size: %d, total copied: %d",
@@ -889,8 +1378,7 @@ func (m *milvusRepository) CopyIndices(ctx context.Context,
 		*offset += count
 	}
 
-	log.Infof("[Milvus] Index copy completed, total copied: %d", totalCopied)
-	return nil
+	return totalCopied, nil
 }
 
 func buildRetrieveResult(results []*types.IndexWithScore, retrieverType types.RetrieverType) []*types.RetrieveResult {
@@ -935,6 +1423,18 @@ func (m *milvusRepository) calculateStorageSize(embedding *MilvusVectorEmbedding
 	return totalSizeBytes
 }
 
+// toStoredEmbedding converts an IndexInfo into the row written to Milvus.
+// In content reference mode the content is dropped: the chunk ID already
+// points at the authoritative copy in the chunk repository, and search
+// results are hydrated from there.
+func (m *milvusRepository) toStoredEmbedding(embedding *types.IndexInfo, additionalParams map[string]interface{}) *MilvusVectorEmbedding {
+	vector := toMilvusVectorEmbedding(embedding, additionalParams)
+	if m.contentReference {
+		vector.Content = ""
+	}
+	return vector
+}
+
 // toMilvusVectorEmbedding converts IndexInfo to Milvus format
 func toMilvusVectorEmbedding(embedding *types.IndexInfo, additionalParams map[string]interface{}) *MilvusVectorEmbedding {
 	vector := &MilvusVectorEmbedding{
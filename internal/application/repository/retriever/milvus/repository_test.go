@@ -2,9 +2,15 @@ package milvus
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/Tencent/WeKnora/internal/types"
 )
 
 func TestUpdateChunkEnabledStatusInCollectionSkipsEmptyChunkIDs(t *testing.T) {
@@ -23,3 +29,194 @@ func TestUpdateChunkEnabledStatusInCollectionSkipsEmptyChunkIDs(t *testing.T) {
 		true,
 	))
 }
+
+// milvusCodedError mimics the coded errors of the Milvus client.
+type milvusCodedError struct{ code int32 }
+
+func (e milvusCodedError) Error() string { return fmt.Sprintf("milvus error code %d", e.code) }
+func (e milvusCodedError) Code() int32   { return e.code }
+
+func TestSetEnabledStatusFallback(t *testing.T) {
+	tests := []struct {
+		name  string
+		err   error
+		latch bool
+	}{
+		{"unavailable", status.Error(codes.Unavailable, "connection refused"), false},
+		{"timeout", fmt.Errorf("upsert: %w", context.DeadlineExceeded), false},
+		{"invalid parameter", milvusCodedError{code: 1100}, false},
+		{"grpc unimplemented", status.Error(codes.Unimplemented, "unknown method"), true},
+		{"milvus unimplemented", fmt.Errorf("upsert: %w", milvusCodedError{code: milvusCodeServiceUnimplemented}), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &milvusRepository{}
+			var partialCalls, fullRowCalls int
+			partial := func([]string) error { partialCalls++; return tt.err }
+			fullRow := func([]string) error { fullRowCalls++; return nil }
+
+			require.NoError(t, repo.setEnabledStatus(context.Background(), []string{"chunk-1"}, partial, fullRow))
+			require.Equal(t, 1, partialCalls)
+			require.Equal(t, 1, fullRowCalls, "a failed partial update falls back to a full-row upsert")
+			require.Equal(t, tt.latch, repo.partialUpdateUnsupported.Load())
+
+			require.NoError(t, repo.setEnabledStatus(context.Background(), []string{"chunk-2"}, partial, fullRow))
+			if tt.latch {
+				require.Equal(t, 1, partialCalls, "unsupported partial updates are not retried")
+			} else {
+				require.Equal(t, 2, partialCalls, "a failure that is not unsupported is retried next time")
+			}
+			require.Equal(t, !tt.latch, repo.Capabilities().PartialUpdate)
+		})
+	}
+}
+
+func TestSetEnabledStatusBatches(t *testing.T) {
+	repo := &milvusRepository{}
+	chunkIDs := make([]string, 2*enabledStatusBatchSize+500)
+	for i := range chunkIDs {
+		chunkIDs[i] = fmt.Sprintf("chunk-%d", i)
+	}
+	var batches []int
+	partial := func(batch []string) error { batches = append(batches, len(batch)); return nil }
+	fullRow := func([]string) error { t.Fatal("unexpected full-row upsert"); return nil }
+
+	require.NoError(t, repo.setEnabledStatus(context.Background(), chunkIDs, partial, fullRow))
+	require.Equal(t, []int{enabledStatusBatchSize, enabledStatusBatchSize, 500}, batches)
+
+	// A failing fallback stops the remaining batches.
+	batches = nil
+	repo.partialUpdateUnsupported.Store(true)
+	err := repo.setEnabledStatus(context.Background(), chunkIDs, partial, func(batch []string) error {
+		batches = append(batches, len(batch))
+		return errors.New("upsert failed")
+	})
+	require.EqualError(t, err, "upsert failed")
+	require.Equal(t, []int{enabledStatusBatchSize}, batches)
+}
+
+func TestAnalyzerConfigParams(t *testing.T) {
+	t.Setenv(envMilvusAnalyzerType, "")
+	t.Setenv(envMilvusAnalyzerStopWords, "")
+
+	// Nothing configured keeps Milvus' default analyzer.
+	require.Nil(t, resolveAnalyzerConfig(nil).params())
+
+	params := resolveAnalyzerConfig(&types.IndexConfig{
+		AnalyzerType:       "Jieba",
+		AnalyzerStopWords:  []string{"的"},
+		AnalyzerDictionary: []string{"向量数据库"},
+	}).params()
+	tokenizer, ok := params["tokenizer"].(map[string]any)
+	require.True(t, ok)
+	require.Equal(t, "jieba", tokenizer["type"])
+	require.Equal(t, []string{jiebaDefaultDict, "向量数据库"}, tokenizer["dict"])
+	filters := params["filter"].([]any)
+	require.Len(t, filters, 2)
+	require.Equal(t, map[string]any{"type": "stop", "stop_words": []string{"的"}}, filters[1])
+
+	params = resolveAnalyzerConfig(&types.IndexConfig{AnalyzerType: types.AnalyzerTypeICU}).params()
+	require.Equal(t, "icu", params["tokenizer"])
+}
+
+func TestAnalyzerConfigEnvFallback(t *testing.T) {
+	t.Setenv(envMilvusAnalyzerType, "ICU")
+	t.Setenv(envMilvusAnalyzerStopWords, "a, the ,")
+
+	cfg := resolveAnalyzerConfig(nil)
+	require.Equal(t, types.AnalyzerTypeICU, cfg.analyzerType)
+	require.Equal(t, []string{"a", "the"}, cfg.stopWords)
+
+	// IndexConfig wins over the environment.
+	cfg = resolveAnalyzerConfig(&types.IndexConfig{AnalyzerType: types.AnalyzerTypeJieba})
+	require.Equal(t, types.AnalyzerTypeJieba, cfg.analyzerType)
+}
+
+func TestParseCollectionName(t *testing.T) {
+	repo := &milvusRepository{collectionBaseName: "weknora_embeddings"}
+
+	dim, modelSpace, ok := repo.parseCollectionName("weknora_embeddings_1024")
+	require.True(t, ok)
+	require.Equal(t, 1024, dim)
+	require.Empty(t, modelSpace)
+
+	dim, modelSpace, ok = repo.parseCollectionName(repo.getModelCollectionName("model-a", 768))
+	require.True(t, ok)
+	require.Equal(t, 768, dim)
+	require.Equal(t, modelSpaceID("model-a"), modelSpace)
+
+	for _, name := range []string{
+		"weknora_embeddings_1024_reindex",
+		"weknora_embeddings_1024_reindex_old",
+		repo.getModelCollectionName("model-a", 768) + reindexStagingSuffix,
+		"weknora_embeddings_1024_mXYZ",
+		"other_1024",
+		"weknora_embeddings",
+	} {
+		_, _, ok := repo.parseCollectionName(name)
+		require.False(t, ok, name)
+	}
+}
+
+func TestModelCollectionName(t *testing.T) {
+	repo := &milvusRepository{collectionBaseName: "weknora_embeddings"}
+
+	// No model keeps writing to the legacy per-dimension collection.
+	require.Equal(t, "weknora_embeddings_1024", repo.getModelCollectionName("", 1024))
+
+	a := repo.getModelCollectionName("model-a", 1024)
+	b := repo.getModelCollectionName("model-b", 1024)
+	require.NotEqual(t, a, b, "same-dimension models must not share a collection")
+	require.Equal(t, a, repo.getModelCollectionName("model-a", 1024))
+	require.Regexp(t, `^weknora_embeddings_1024_m[0-9a-f]{16}$`, a)
+}
+
+func TestContentReferenceMode(t *testing.T) {
+	repo := &milvusRepository{contentReference: true}
+	require.Equal(t, []types.RetrieverType{types.VectorRetrieverType}, repo.Support())
+
+	stored := repo.toStoredEmbedding(&types.IndexInfo{
+		Content:  "large chunk body",
+		SourceID: "chunk-1",
+		ChunkID:  "chunk-1",
+	}, map[string]any{fieldEmbedding: map[string][]float32{"chunk-1": {0.1, 0.2}}})
+	require.Empty(t, stored.Content)
+	require.Equal(t, "chunk-1", stored.ChunkID)
+	require.Len(t, stored.Embedding, 2)
+
+	results, err := repo.KeywordsRetrieve(context.Background(), types.RetrieveParams{Query: "q"})
+	require.NoError(t, err)
+	require.Empty(t, results[0].Results)
+}
+
+func TestResolveLoadPolicy(t *testing.T) {
+	t.Setenv(envMilvusLoadPolicy, "")
+	require.Equal(t, types.LoadPolicyEager, resolveLoadPolicy(nil))
+	require.Equal(t, types.LoadPolicyMmap, resolveLoadPolicy(&types.IndexConfig{LoadPolicy: "MMAP"}))
+
+	t.Setenv(envMilvusLoadPolicy, "lazy")
+	require.Equal(t, types.LoadPolicyLazy, resolveLoadPolicy(nil))
+	require.Equal(t, types.LoadPolicyEager, resolveLoadPolicy(&types.IndexConfig{LoadPolicy: "eager"}))
+
+	t.Setenv(envMilvusLoadPolicy, "preload")
+	require.Equal(t, types.LoadPolicyEager, resolveLoadPolicy(nil))
+}
+
+func TestAwaitLoadWaitsForTrackedLoad(t *testing.T) {
+	repo := &milvusRepository{}
+	ctx := context.Background()
+	require.NoError(t, repo.awaitLoad(ctx, "untracked"), "collections loaded before startup are not waited on")
+
+	load := &collectionLoad{done: make(chan struct{})}
+	repo.loads.Store("weknora_embeddings_768", load)
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	require.ErrorIs(t, repo.awaitLoad(canceled, "weknora_embeddings_768"), context.Canceled)
+
+	close(load.done)
+	require.NoError(t, repo.awaitLoad(ctx, "weknora_embeddings_768"))
+
+	repo.forgetCollection("weknora_embeddings_768")
+	_, tracked := repo.loads.Load("weknora_embeddings_768")
+	require.False(t, tracked)
+}
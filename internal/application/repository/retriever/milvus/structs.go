@@ -2,7 +2,9 @@ package milvus
 
 import (
 	"sync"
+	"sync/atomic"
 
+	"github.com/Tencent/WeKnora/internal/utils"
 	"github.com/milvus-io/milvus/client/v2/entity"
 	client "github.com/milvus-io/milvus/client/v2/milvusclient"
 )
@@ -12,10 +14,21 @@ type milvusRepository struct {
 	client             *client.Client
 	collectionBaseName string
 	metricType         entity.MetricType
-	shardsNum          int // 0 = use Milvus default (1)
-	replicaNumber      int // 0 = use Milvus default (1); set at LoadCollection time
-	// Cache for initialized collections (dimension -> true)
+	shardsNum          int            // 0 = use Milvus default (1)
+	replicaNumber      int            // 0 = use Milvus default (1); set at LoadCollection time
+	analyzer           analyzerConfig // content field analyzer; applied at CreateCollection time
+	contentReference   bool           // store chunk ID only; content is hydrated from the chunk repository
+	loadPolicy         string         // types.LoadPolicy*: when and how collections are loaded
+	// Cache for initialized collections (collection name -> true)
 	initializedCollections sync.Map
+	// In-flight and finished LoadCollection calls (collection name -> *collectionLoad)
+	loads sync.Map
+	// Holds writes off a collection while it is re-counted and dropped as empty
+	dropGuard utils.DropGuard
+	// partialUpdateUnsupported is set once the server reported partial
+	// upserts as unimplemented, so enabled-status updates skip straight to
+	// full-row upserts.
+	partialUpdateUnsupported atomic.Bool
 }
 
 type MilvusVectorEmbedding struct {
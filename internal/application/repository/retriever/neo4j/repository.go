@@ -3,6 +3,7 @@ package neo4j
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -227,6 +228,86 @@ func (n *Neo4jRepository) SearchNode(
 	return result.(*types.GraphData), nil
 }
 
+// GetGraph returns the whole graph of a namespace from the Neo4j repository
+func (n *Neo4jRepository) GetGraph(ctx context.Context, namespace types.NameSpace) (*types.GraphData, error) {
+	if n.driver == nil {
+		logger.Warnf(ctx, "NOT SUPPORT RETRIEVE GRAPH")
+		return nil, nil
+	}
+	session := n.driver.NewSession(ctx, neo4j.SessionConfig{AccessMode: neo4j.AccessModeRead})
+	defer session.Close(ctx)
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		labelExpr := n.Label(namespace)
+		query := `
+			MATCH (n:` + labelExpr + `)
+			OPTIONAL MATCH (n)-[r]->(m:` + labelExpr + `)
+			RETURN n, r, m
+		`
+		result, err := tx.Run(ctx, query, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run query: %v", err)
+		}
+
+		graphData := &types.GraphData{}
+		nodes := make(map[string]*types.GraphNode)
+		addNode := func(node neo4j.Node) {
+			name, _ := node.Props["name"].(string)
+			chunks, _ := node.Props["chunks"].([]interface{})
+			attributes, _ := node.Props["attributes"].([]interface{})
+			existing, ok := nodes[name]
+			if !ok {
+				existing = &types.GraphNode{Name: name}
+				nodes[name] = existing
+				graphData.Node = append(graphData.Node, existing)
+			}
+			existing.Chunks = unionStrings(existing.Chunks, listI2listS(chunks))
+			existing.Attributes = unionStrings(existing.Attributes, listI2listS(attributes))
+		}
+		relSeen := make(map[string]bool)
+		for result.Next(ctx) {
+			record := result.Record()
+			node, _ := record.Get("n")
+			nodeData := node.(neo4j.Node)
+			addNode(nodeData)
+
+			rel, _ := record.Get("r")
+			targetNode, _ := record.Get("m")
+			if rel == nil || targetNode == nil {
+				continue
+			}
+			targetNodeData := targetNode.(neo4j.Node)
+			addNode(targetNodeData)
+			relation := &types.GraphRelation{
+				Node1: nodeData.Props["name"].(string),
+				Node2: targetNodeData.Props["name"].(string),
+				Type:  rel.(neo4j.Relationship).Type,
+			}
+			key := relation.Node1 + "\x00" + relation.Node2 + "\x00" + relation.Type
+			if !relSeen[key] {
+				relSeen[key] = true
+				graphData.Relation = append(graphData.Relation, relation)
+			}
+		}
+		return graphData, nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "get graph failed: %v", err)
+		return nil, err
+	}
+	return result.(*types.GraphData), nil
+}
+
+// unionStrings appends the values of extra missing from list
+func unionStrings(list []string, extra []string) []string {
+	for _, v := range extra {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
 func listI2listS(list []any) []string {
 	result := make([]string, len(list))
 	for i, v := range list {
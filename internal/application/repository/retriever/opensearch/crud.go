@@ -349,8 +349,24 @@ func (r *Repository) DeleteByKnowledgeIDList(
 	return r.deleteByList(ctx, knowledgeIDs, dim, "knowledge_id")
 }
 
+// DeleteByKnowledgeBaseIDList and DeleteByTagIDList share the 1000-id cap
+// of the other DeleteBy* methods. Unlike the per-dimension collections of
+// Milvus / Qdrant, an emptied index is kept: the versioned index behind the
+// per-dim alias is owned by ensureReady and recreating it is not free.
+func (r *Repository) DeleteByKnowledgeBaseIDList(
+	ctx context.Context, knowledgeBaseIDs []string, dim int, _ string,
+) error {
+	return r.deleteByList(ctx, knowledgeBaseIDs, dim, "knowledge_base_id")
+}
+
+func (r *Repository) DeleteByTagIDList(
+	ctx context.Context, tagIDs []string, dim int, _ string,
+) error {
+	return r.deleteByList(ctx, tagIDs, dim, "tag_id")
+}
+
 // deleteByList factors the common cap / empty / ensureReady / dispatch
-// logic out of the three DeleteBy* methods. dim==0 routes to the
+// logic out of the DeleteBy* methods. dim==0 routes to the
 // dim-less keywords index.
 func (r *Repository) deleteByList(
 	ctx context.Context, ids []string, dim int, field string,
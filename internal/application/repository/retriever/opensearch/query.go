@@ -39,8 +39,8 @@ func fromParams(p types.RetrieveParams) *retrieveFilters {
 		KBIDs:               p.KnowledgeBaseIDs,
 		KnowledgeIDs:        p.KnowledgeIDs,
 		TagIDs:              p.TagIDs,
-		ExcludeChunkIDs:     p.ExcludeChunkIDs,
-		ExcludeKnowledgeIDs: p.ExcludeKnowledgeIDs,
+		ExcludeChunkIDs:     p.ExcludedChunkIDs(),
+		ExcludeKnowledgeIDs: p.ExcludedKnowledgeIDs(),
 		// IncludeDisabled stays false — set explicitly by admin callers
 		// only. Driver receives this from a typed field, not from
 		// AdditionalParams, so the contract is checked at compile time.
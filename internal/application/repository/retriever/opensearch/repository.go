@@ -242,6 +242,15 @@ func (r *Repository) Support() []types.RetrieverType {
 	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
 }
 
+// Capabilities reports OpenSearch features: field updates are scripted
+// bulk updates, and TopK is clamped to the index.max_result_window default.
+func (r *Repository) Capabilities() types.EngineCapabilities {
+	caps := types.NewEngineCapabilities(r.EngineType(), r.Support())
+	caps.PartialUpdate = true
+	caps.MaxTopK = 10000
+	return caps
+}
+
 // probeVersion sends GET / and validates the cluster is OpenSearch in
 // a supported version range:
 //   - Reject: ES (any), OS 1.x, OS 2.0~2.3 (Lucene HNSW preview).
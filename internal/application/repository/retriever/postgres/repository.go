@@ -37,6 +37,14 @@ func (r *pgRepository) Support() []types.RetrieverType {
 	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
 }
 
+// Capabilities reports PostgreSQL features: field updates are plain
+// UPDATE statements.
+func (r *pgRepository) Capabilities() types.EngineCapabilities {
+	caps := types.NewEngineCapabilities(r.EngineType(), r.Support())
+	caps.PartialUpdate = true
+	return caps
+}
+
 // calculateIndexStorageSize calculates storage size for a single index entry
 func (g *pgRepository) calculateIndexStorageSize(embeddingDB *pgVector) int64 {
 	// 1. Text content size
@@ -146,6 +154,36 @@ func (g *pgRepository) DeleteByKnowledgeIDList(ctx context.Context, knowledgeIDL
 	return nil
 }
 
+// DeleteByKnowledgeBaseIDList deletes every index of the given knowledge bases
+func (g *pgRepository) DeleteByKnowledgeBaseIDList(ctx context.Context, knowledgeBaseIDList []string, dimension int, knowledgeType string) error {
+	if len(knowledgeBaseIDList) == 0 {
+		return nil
+	}
+	logger.GetLogger(ctx).Infof("[Postgres] Deleting indices by knowledge base IDs, count: %d", len(knowledgeBaseIDList))
+	result := g.db.WithContext(ctx).Where("knowledge_base_id IN ?", knowledgeBaseIDList).Delete(&pgVector{})
+	if result.Error != nil {
+		logger.GetLogger(ctx).Errorf("[Postgres] Failed to delete indices by knowledge base IDs: %v", result.Error)
+		return result.Error
+	}
+	logger.GetLogger(ctx).Infof("[Postgres] Successfully deleted %d indices by knowledge base IDs", result.RowsAffected)
+	return nil
+}
+
+// DeleteByTagIDList deletes indices by tag IDs
+func (g *pgRepository) DeleteByTagIDList(ctx context.Context, tagIDList []string, dimension int, knowledgeType string) error {
+	if len(tagIDList) == 0 {
+		return nil
+	}
+	logger.GetLogger(ctx).Infof("[Postgres] Deleting indices by tag IDs, count: %d", len(tagIDList))
+	result := g.db.WithContext(ctx).Where("tag_id IN ?", tagIDList).Delete(&pgVector{})
+	if result.Error != nil {
+		logger.GetLogger(ctx).Errorf("[Postgres] Failed to delete indices by tag IDs: %v", result.Error)
+		return result.Error
+	}
+	logger.GetLogger(ctx).Infof("[Postgres] Successfully deleted %d indices by tag IDs", result.RowsAffected)
+	return nil
+}
+
 // Retrieve handles retrieval requests and routes to appropriate method
 func (g *pgRepository) Retrieve(ctx context.Context, params types.RetrieveParams) ([]*types.RetrieveResult, error) {
 	logger.GetLogger(ctx).Debugf("[Postgres] Processing retrieval request of type: %s", params.RetrieverType)
@@ -193,6 +231,20 @@ func (g *pgRepository) KeywordsRetrieve(ctx context.Context,
 			Values: common.ToInterfaceSlice(params.TagIDs),
 		})
 	}
+	// Drop excluded documents and those the caller's ACL does not match
+	if excluded := params.ExcludedKnowledgeIDs(); len(excluded) > 0 {
+		conds = append(conds, clause.Not(clause.IN{
+			Column: "knowledge_id",
+			Values: common.ToInterfaceSlice(excluded),
+		}))
+	}
+	// Drop excluded chunks and those staged by an index migration
+	if excludedChunks := params.ExcludedChunkIDs(); len(excludedChunks) > 0 {
+		conds = append(conds, clause.Not(clause.IN{
+			Column: "chunk_id",
+			Values: common.ToInterfaceSlice(excludedChunks),
+		}))
+	}
 
 	// Use ParadeDB's ||| operator for matching any token
 	conds = append(conds, clause.Expr{
@@ -329,6 +381,28 @@ func (g *pgRepository) VectorRetrieve(ctx context.Context,
 		whereParts = append(whereParts, fmt.Sprintf("tag_id IN (%s)",
 			strings.Join(placeholders, ", ")))
 	}
+	// Drop excluded documents and those the caller's ACL does not match
+	if excluded := params.ExcludedKnowledgeIDs(); len(excluded) > 0 {
+		placeholders := make([]string, len(excluded))
+		paramStart := len(allVars) + 1
+		for i := range excluded {
+			placeholders[i] = fmt.Sprintf("$%d", paramStart+i)
+			allVars = append(allVars, excluded[i])
+		}
+		whereParts = append(whereParts, fmt.Sprintf("knowledge_id NOT IN (%s)",
+			strings.Join(placeholders, ", ")))
+	}
+	// Drop excluded chunks and those staged by an index migration
+	if excludedChunks := params.ExcludedChunkIDs(); len(excludedChunks) > 0 {
+		placeholders := make([]string, len(excludedChunks))
+		paramStart := len(allVars) + 1
+		for i := range excludedChunks {
+			placeholders[i] = fmt.Sprintf("$%d", paramStart+i)
+			allVars = append(allVars, excludedChunks[i])
+		}
+		whereParts = append(whereParts, fmt.Sprintf("chunk_id NOT IN (%s)",
+			strings.Join(placeholders, ", ")))
+	}
 
 	// is_enabled filter
 	whereParts = append(whereParts, fmt.Sprintf("(is_enabled IS NULL OR is_enabled = $%d)", len(allVars)+1))
@@ -615,6 +689,84 @@ func (g *pgRepository) CopyIndices(ctx context.Context,
 	return nil
 }
 
+// ExportIndices streams the index rows of a knowledge base with their
+// vectors, batchSize rows at a time, in id order; see interfaces.IndexExporter.
+func (g *pgRepository) ExportIndices(ctx context.Context,
+	knowledgeBaseID string, batchSize int, fn func([]*types.ExportedIndex) error,
+) error {
+	var lastID uint
+	for {
+		var rows []*pgVector
+		if err := g.db.WithContext(ctx).
+			Where("knowledge_base_id = ? AND id > ?", knowledgeBaseID, lastID).
+			Order("id").
+			Limit(batchSize).
+			Find(&rows).Error; err != nil {
+			logger.GetLogger(ctx).Errorf("[Postgres] Failed to export index data: %v", err)
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		batch := make([]*types.ExportedIndex, 0, len(rows))
+		for _, row := range rows {
+			batch = append(batch, &types.ExportedIndex{
+				SourceID:    row.SourceID,
+				SourceType:  types.SourceType(row.SourceType),
+				ChunkID:     row.ChunkID,
+				KnowledgeID: row.KnowledgeID,
+				TagID:       row.TagID,
+				Content:     row.Content,
+				IsEnabled:   row.IsEnabled,
+				Embedding:   row.Embedding.Slice(),
+			})
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		lastID = rows[len(rows)-1].ID
+		if len(rows) < batchSize {
+			return nil
+		}
+	}
+}
+
+// MoveIndices reassigns the index rows of the given knowledge to another
+// knowledge base in one transaction; see interfaces.IndexMover.
+func (g *pgRepository) MoveIndices(ctx context.Context,
+	knowledgeIDs []string, targetKnowledgeBaseID string, chunkTagMap map[string]string,
+) error {
+	if len(knowledgeIDs) == 0 {
+		return nil
+	}
+	tagGroups := make(map[string][]string)
+	for chunkID, tagID := range chunkTagMap {
+		tagGroups[tagID] = append(tagGroups[tagID], chunkID)
+	}
+	err := g.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&pgVector{}).
+			Where("knowledge_id IN ?", knowledgeIDs).
+			Update("knowledge_base_id", targetKnowledgeBaseID)
+		if result.Error != nil {
+			return result.Error
+		}
+		logger.GetLogger(ctx).Infof("[Postgres] Moved %d indices to knowledge base %s",
+			result.RowsAffected, targetKnowledgeBaseID)
+		for tagID, chunkIDs := range tagGroups {
+			if err := tx.Model(&pgVector{}).
+				Where("knowledge_id IN ? AND chunk_id IN ?", knowledgeIDs, chunkIDs).
+				Update("tag_id", tagID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.GetLogger(ctx).Errorf("[Postgres] Failed to move indices: %v", err)
+	}
+	return err
+}
+
 // BatchUpdateChunkEnabledStatus updates the enabled status of chunks in batch
 func (g *pgRepository) BatchUpdateChunkEnabledStatus(ctx context.Context, chunkStatusMap map[string]bool) error {
 	if len(chunkStatusMap) == 0 {
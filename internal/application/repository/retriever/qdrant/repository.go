@@ -3,12 +3,16 @@ package qdrant
 import (
 	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"unicode/utf8"
 
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
 	"github.com/google/uuid"
 	"github.com/qdrant/go-client/qdrant"
 )
@@ -25,6 +29,9 @@ const (
 	fieldTagID            = "tag_id"
 	fieldEmbedding        = "embedding"
 	fieldIsEnabled        = "is_enabled"
+	// collectionConcurrency bounds how many dimension collections one call
+	// works on at the same time.
+	collectionConcurrency = 4
 )
 
 // NewQdrantRetrieveEngineRepository creates and initializes a new Qdrant repository.
@@ -145,6 +152,14 @@ func (q *qdrantRepository) Support() []types.RetrieverType {
 	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
 }
 
+// Capabilities reports Qdrant features: field updates set payload keys
+// in place.
+func (q *qdrantRepository) Capabilities() types.EngineCapabilities {
+	caps := types.NewEngineCapabilities(q.EngineType(), q.Support())
+	caps.PartialUpdate = true
+	return caps
+}
+
 // EstimateStorageSize calculates the estimated storage size for a list of indices
 func (q *qdrantRepository) EstimateStorageSize(ctx context.Context,
 	indexInfoList []*types.IndexInfo, params map[string]any,
@@ -176,21 +191,21 @@ func (q *qdrantRepository) Save(ctx context.Context,
 	}
 
 	dimension := len(embeddingDB.Embedding)
-	if err := q.ensureCollection(ctx, dimension); err != nil {
-		return err
-	}
-
 	collectionName := q.getCollectionName(dimension)
-	pointID := uuid.New().String()
+	// A deterministic point ID makes a retried Save overwrite, not duplicate.
+	pointID := embedding.RowID()
 	point := &qdrant.PointStruct{
 		Id:      qdrant.NewID(pointID),
 		Vectors: qdrant.NewVectors(embeddingDB.Embedding...),
 		Payload: createPayload(embeddingDB),
 	}
 
-	_, err := q.client.Upsert(ctx, &qdrant.UpsertPoints{
-		CollectionName: collectionName,
-		Points:         []*qdrant.PointStruct{point},
+	err := q.writeCollection(ctx, dimension, func() error {
+		_, err := q.client.Upsert(ctx, &qdrant.UpsertPoints{
+			CollectionName: collectionName,
+			Points:         []*qdrant.PointStruct{point},
+		})
+		return err
 	})
 	if err != nil {
 		log.Errorf("[Qdrant] Failed to save index: %v", err)
@@ -225,7 +240,7 @@ func (q *qdrantRepository) BatchSave(ctx context.Context,
 
 		dimension := len(embeddingDB.Embedding)
 		point := &qdrant.PointStruct{
-			Id:      qdrant.NewID(uuid.New().String()),
+			Id:      qdrant.NewID(embedding.RowID()),
 			Vectors: qdrant.NewVectors(embeddingDB.Embedding...),
 			Payload: createPayload(embeddingDB),
 		}
@@ -238,16 +253,32 @@ func (q *qdrantRepository) BatchSave(ctx context.Context,
 		return nil
 	}
 
-	// Save points to each dimension-specific collection
-	totalSaved := 0
-	const batchSize = 100
-	for dimension, points := range pointsByDimension {
-		if err := q.ensureCollection(ctx, dimension); err != nil {
-			return err
-		}
+	// Save points to the dimension-specific collections concurrently. A
+	// failing collection does not stop the others; all failures are reported.
+	var totalSaved atomic.Int64
+	err := utils.ForEachLimit(slices.Sorted(maps.Keys(pointsByDimension)), collectionConcurrency,
+		func(_ int, dimension int) error {
+			points := pointsByDimension[dimension]
+			if err := q.upsertPoints(ctx, dimension, points); err != nil {
+				return err
+			}
+			totalSaved.Add(int64(len(points)))
+			return nil
+		})
+	if err != nil {
+		return err
+	}
 
-		collectionName := q.getCollectionName(dimension)
+	log.Infof("[Qdrant] Successfully batch saved %d indices", totalSaved.Load())
+	return nil
+}
 
+// upsertPoints writes points to the collection of the given dimension in
+// batches, creating the collection first if needed.
+func (q *qdrantRepository) upsertPoints(ctx context.Context, dimension int, points []*qdrant.PointStruct) error {
+	collectionName := q.getCollectionName(dimension)
+	const batchSize = 100
+	err := q.writeCollection(ctx, dimension, func() error {
 		for i := 0; i < len(points); i += batchSize {
 			end := i + batchSize
 			if end > len(points) {
@@ -260,17 +291,45 @@ func (q *qdrantRepository) BatchSave(ctx context.Context,
 				Points:         batch,
 			})
 			if err != nil {
-				return fmt.Errorf("failed to upsert batch: %w", err)
+				return fmt.Errorf("failed to upsert batch (collection %s): %w", collectionName, err)
 			}
 		}
-		totalSaved += len(points)
-		log.Infof("[Qdrant] Saved %d points to collection %s", len(points), collectionName)
+		return nil
+	})
+	if err != nil {
+		return err
 	}
-
-	log.Infof("[Qdrant] Successfully batch saved %d indices", totalSaved)
+	logger.GetLogger(ctx).Infof("[Qdrant] Saved %d points to collection %s", len(points), collectionName)
 	return nil
 }
 
+// writeCollection runs write against the collection of the dimension,
+// creating it first if needed, while this process cannot drop it as empty.
+// A collection that another instance dropped after this one cached it is
+// recreated and the write retried once.
+func (q *qdrantRepository) writeCollection(ctx context.Context, dimension int, write func() error) error {
+	collectionName := q.getCollectionName(dimension)
+	return q.dropGuard.Write(collectionName, func() error {
+		if err := q.ensureCollection(ctx, dimension); err != nil {
+			return err
+		}
+		err := write()
+		if err == nil {
+			return nil
+		}
+		exists, existsErr := q.client.CollectionExists(ctx, collectionName)
+		if existsErr != nil || exists {
+			return err
+		}
+		logger.GetLogger(ctx).Warnf("[Qdrant] Collection %s was dropped elsewhere, recreating it", collectionName)
+		q.initializedCollections.Delete(dimension)
+		if err := q.ensureCollection(ctx, dimension); err != nil {
+			return err
+		}
+		return write()
+	})
+}
+
 // DeleteByChunkIDList removes points from the collection based on chunk IDs
 func (q *qdrantRepository) DeleteByChunkIDList(ctx context.Context, chunkIDList []string, dimension int, knowledgeType string) error {
 	log := logger.GetLogger(ctx)
@@ -359,6 +418,92 @@ func (q *qdrantRepository) DeleteBySourceIDList(ctx context.Context,
 	return nil
 }
 
+// DeleteByKnowledgeBaseIDList removes every point of the given knowledge
+// bases and drops the collection of the dimension if it is left empty
+func (q *qdrantRepository) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	if len(knowledgeBaseIDList) == 0 {
+		logger.GetLogger(ctx).Warn("[Qdrant] Empty knowledge base ID list provided for deletion, skipping")
+		return nil
+	}
+	return q.deleteByField(ctx, fieldKnowledgeBaseID, knowledgeBaseIDList, dimension)
+}
+
+// DeleteByTagIDList removes points based on tag IDs and drops the collection
+// of the dimension if it is left empty
+func (q *qdrantRepository) DeleteByTagIDList(ctx context.Context,
+	tagIDList []string, dimension int, knowledgeType string,
+) error {
+	if len(tagIDList) == 0 {
+		logger.GetLogger(ctx).Warn("[Qdrant] Empty tag ID list provided for deletion, skipping")
+		return nil
+	}
+	return q.deleteByField(ctx, fieldTagID, tagIDList, dimension)
+}
+
+// deleteByField deletes the points whose field matches one of values and
+// drops the per-dimension collection once no points remain, so tearing down
+// the last knowledge base of a dimension leaves nothing behind. The points
+// are counted again with this process's writes to the collection held off,
+// and the collection is evicted from the cache before they resume, so a
+// concurrent write either lands first and keeps the collection or recreates
+// it through ensureCollection.
+func (q *qdrantRepository) deleteByField(ctx context.Context,
+	field string, values []string, dimension int,
+) error {
+	log := logger.GetLogger(ctx)
+	collectionName := q.getCollectionName(dimension)
+	exists, err := q.client.CollectionExists(ctx, collectionName)
+	if err != nil {
+		log.Errorf("[Qdrant] Failed to check collection existence: %v", err)
+		return fmt.Errorf("failed to check collection existence: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+
+	log.Infof("[Qdrant] Deleting indices by %s from %s, count: %d", field, collectionName, len(values))
+	_, err = q.client.Delete(ctx, &qdrant.DeletePoints{
+		CollectionName: collectionName,
+		Wait:           qdrant.PtrOf(true),
+		Points: qdrant.NewPointsSelectorFilter(&qdrant.Filter{
+			Must: []*qdrant.Condition{
+				qdrant.NewMatchKeywords(field, values...),
+			},
+		}),
+	})
+	if err != nil {
+		log.Errorf("[Qdrant] Failed to delete by %s: %v", field, err)
+		return fmt.Errorf("failed to delete by %s: %w", field, err)
+	}
+
+	dropped, err := q.dropGuard.DropIfEmpty(collectionName,
+		func() (int, error) {
+			remaining, err := q.client.Count(ctx, &qdrant.CountPoints{
+				CollectionName: collectionName,
+				Exact:          qdrant.PtrOf(true),
+			})
+			return int(remaining), err
+		},
+		func() error {
+			if err := q.client.DeleteCollection(ctx, collectionName); err != nil {
+				return err
+			}
+			q.initializedCollections.Delete(dimension)
+			return nil
+		})
+	if err != nil {
+		// The delete succeeded; a failed count or drop only skips the cleanup.
+		log.Warnf("[Qdrant] Skipping empty collection cleanup of %s: %v", collectionName, err)
+	} else if dropped {
+		log.Infof("[Qdrant] Dropped empty collection %s", collectionName)
+	}
+
+	log.Infof("[Qdrant] Successfully deleted documents by %s", field)
+	return nil
+}
+
 // BatchUpdateChunkEnabledStatus updates the enabled status of chunks in batch
 // This method operates on all collections since dimension is not provided
 func (q *qdrantRepository) BatchUpdateChunkEnabledStatus(ctx context.Context, chunkStatusMap map[string]bool) error {
@@ -389,49 +534,55 @@ func (q *qdrantRepository) BatchUpdateChunkEnabledStatus(ctx context.Context, ch
 		}
 	}
 
-	// Update in all matching collections
+	// Update in all matching collections concurrently
+	_ = utils.ForEachLimit(q.ownCollections(collections), collectionConcurrency, func(_ int, collectionName string) error {
+		q.setEnabledPayload(ctx, collectionName, enabledChunkIDs, disabledChunkIDs)
+		return nil
+	})
+
+	log.Infof("[Qdrant] Batch update chunk enabled status completed")
+	return nil
+}
+
+// ownCollections keeps the collections named after this repository's base
+// name, i.e. the ones holding its embeddings.
+func (q *qdrantRepository) ownCollections(collections []string) []string {
+	own := make([]string, 0, len(collections))
 	for _, collectionName := range collections {
-		// Only process collections that start with our base name
-		if len(collectionName) <= len(q.collectionBaseName) ||
-			collectionName[:len(q.collectionBaseName)] != q.collectionBaseName {
-			continue
+		if len(collectionName) > len(q.collectionBaseName) && strings.HasPrefix(collectionName, q.collectionBaseName) {
+			own = append(own, collectionName)
 		}
+	}
+	return own
+}
 
-		// Update enabled chunks
-		if len(enabledChunkIDs) > 0 {
-			_, err := q.client.SetPayload(ctx, &qdrant.SetPayloadPoints{
-				CollectionName: collectionName,
-				Payload:        qdrant.NewValueMap(map[string]any{fieldIsEnabled: true}),
-				PointsSelector: qdrant.NewPointsSelectorFilter(&qdrant.Filter{
-					Must: []*qdrant.Condition{
-						qdrant.NewMatchKeywords(fieldChunkID, enabledChunkIDs...),
-					},
-				}),
-			})
-			if err != nil {
-				log.Warnf("[Qdrant] Failed to update enabled chunks in %s: %v", collectionName, err)
-			}
+// setEnabledPayload updates the is_enabled payload of the given chunks in
+// one collection. Failures are logged; the caller updates every collection
+// regardless.
+func (q *qdrantRepository) setEnabledPayload(
+	ctx context.Context, collectionName string, enabledChunkIDs, disabledChunkIDs []string,
+) {
+	log := logger.GetLogger(ctx)
+	for _, update := range []struct {
+		chunkIDs []string
+		enabled  bool
+	}{{enabledChunkIDs, true}, {disabledChunkIDs, false}} {
+		if len(update.chunkIDs) == 0 {
+			continue
 		}
-
-		// Update disabled chunks
-		if len(disabledChunkIDs) > 0 {
-			_, err := q.client.SetPayload(ctx, &qdrant.SetPayloadPoints{
-				CollectionName: collectionName,
-				Payload:        qdrant.NewValueMap(map[string]any{fieldIsEnabled: false}),
-				PointsSelector: qdrant.NewPointsSelectorFilter(&qdrant.Filter{
-					Must: []*qdrant.Condition{
-						qdrant.NewMatchKeywords(fieldChunkID, disabledChunkIDs...),
-					},
-				}),
-			})
-			if err != nil {
-				log.Warnf("[Qdrant] Failed to update disabled chunks in %s: %v", collectionName, err)
-			}
+		_, err := q.client.SetPayload(ctx, &qdrant.SetPayloadPoints{
+			CollectionName: collectionName,
+			Payload:        qdrant.NewValueMap(map[string]any{fieldIsEnabled: update.enabled}),
+			PointsSelector: qdrant.NewPointsSelectorFilter(&qdrant.Filter{
+				Must: []*qdrant.Condition{
+					qdrant.NewMatchKeywords(fieldChunkID, update.chunkIDs...),
+				},
+			}),
+		})
+		if err != nil {
+			log.Warnf("[Qdrant] Failed to update chunks to enabled=%v in %s: %v", update.enabled, collectionName, err)
 		}
 	}
-
-	log.Infof("[Qdrant] Batch update chunk enabled status completed")
-	return nil
 }
 
 // BatchUpdateChunkTagID updates the tag ID of chunks in batch
@@ -508,12 +659,12 @@ func (q *qdrantRepository) getBaseFilter(params types.RetrieveParams) *qdrant.Fi
 		must = append(must, qdrant.NewMatchKeywords(fieldTagID, params.TagIDs...))
 	}
 
-	if len(params.ExcludeKnowledgeIDs) > 0 {
-		mustNot = append(mustNot, qdrant.NewMatchKeywords(fieldKnowledgeID, params.ExcludeKnowledgeIDs...))
+	if excluded := params.ExcludedKnowledgeIDs(); len(excluded) > 0 {
+		mustNot = append(mustNot, qdrant.NewMatchKeywords(fieldKnowledgeID, excluded...))
 	}
 
-	if len(params.ExcludeChunkIDs) > 0 {
-		mustNot = append(mustNot, qdrant.NewMatchKeywords(fieldChunkID, params.ExcludeChunkIDs...))
+	if excludedChunks := params.ExcludedChunkIDs(); len(excludedChunks) > 0 {
+		mustNot = append(mustNot, qdrant.NewMatchKeywords(fieldChunkID, excludedChunks...))
 	}
 
 	filter := &qdrant.Filter{
@@ -629,7 +780,6 @@ func (q *qdrantRepository) KeywordsRetrieve(ctx context.Context,
 		return nil, fmt.Errorf("failed to list collections: %w", err)
 	}
 
-	var allResults []*types.IndexWithScore
 	limit := uint32(params.TopK)
 
 	log.Debugf("[Qdrant] Found %d collections, base name: %s", len(collections), q.collectionBaseName)
@@ -638,64 +788,17 @@ func (q *qdrantRepository) KeywordsRetrieve(ctx context.Context,
 	queryTokens := tokenizeQuery(params.Query)
 	log.Debugf("[Qdrant] Tokenized query into %d tokens: %v", len(queryTokens), queryTokens)
 
-	// Search in all matching collections
-	for _, collectionName := range collections {
-		log.Debugf("[Qdrant] Checking collection: %s", collectionName)
-		// Only process collections that start with our base name
-		if len(collectionName) <= len(q.collectionBaseName) ||
-			collectionName[:len(q.collectionBaseName)] != q.collectionBaseName {
-			log.Debugf("[Qdrant] Skipping collection %s (doesn't match base name %s)", collectionName, q.collectionBaseName)
-			continue
-		}
-
-		filter := q.getBaseFilter(params)
-
-		// Build should conditions for each token (OR logic)
-		// This allows matching documents that contain any of the query tokens
-		if len(queryTokens) > 0 {
-			shouldConditions := make([]*qdrant.Condition, 0, len(queryTokens))
-			for _, token := range queryTokens {
-				shouldConditions = append(shouldConditions, qdrant.NewMatchText(fieldContent, token))
-			}
-			filter.Should = shouldConditions
-		} else {
-			// Fallback to original query if tokenization fails
-			filter.Must = append(filter.Must, qdrant.NewMatchText(fieldContent, params.Query))
-		}
-
-		log.Debugf("[Qdrant] Searching in collection %s with %d should conditions", collectionName, len(filter.Should))
-
-		scrollResult, err := q.client.Scroll(ctx, &qdrant.ScrollPoints{
-			CollectionName: collectionName,
-			Filter:         filter,
-			Limit:          &limit,
-			WithPayload:    qdrant.NewWithPayload(true),
-		})
-		if err != nil {
-			log.Warnf("[Qdrant] Keywords search failed in %s: %v", collectionName, err)
-			continue
-		}
-
-		log.Debugf("[Qdrant] Found %d results in collection %s", len(scrollResult), collectionName)
-
-		for _, point := range scrollResult {
-			payload := point.Payload
-			embedding := &QdrantVectorEmbeddingWithScore{
-				QdrantVectorEmbedding: QdrantVectorEmbedding{
-					Content:         payload[fieldContent].GetStringValue(),
-					SourceID:        payload[fieldSourceID].GetStringValue(),
-					SourceType:      int(payload[fieldSourceType].GetIntegerValue()),
-					ChunkID:         payload[fieldChunkID].GetStringValue(),
-					KnowledgeID:     payload[fieldKnowledgeID].GetStringValue(),
-					KnowledgeBaseID: payload[fieldKnowledgeBaseID].GetStringValue(),
-					TagID:           payload[fieldTagID].GetStringValue(),
-				},
-				Score: 1.0,
-			}
-
-			pointID := point.Id.GetUuid()
-			allResults = append(allResults, fromQdrantVectorEmbedding(pointID, embedding, types.MatchTypeKeywords))
-		}
+	// Search all matching collections concurrently; each call owns its slot
+	// so results keep the collection order.
+	collections = q.ownCollections(collections)
+	resultsByCollection := make([][]*types.IndexWithScore, len(collections))
+	_ = utils.ForEachLimit(collections, collectionConcurrency, func(i int, collectionName string) error {
+		resultsByCollection[i] = q.keywordsSearchCollection(ctx, collectionName, params, queryTokens, limit)
+		return nil
+	})
+	var allResults []*types.IndexWithScore
+	for _, results := range resultsByCollection {
+		allResults = append(allResults, results...)
 	}
 
 	// Limit results to topK
@@ -712,6 +815,64 @@ func (q *qdrantRepository) KeywordsRetrieve(ctx context.Context,
 	return buildRetrieveResult(allResults, types.KeywordsRetrieverType), nil
 }
 
+// keywordsSearchCollection runs the keyword scroll in one collection. A
+// failing collection is logged and contributes no results.
+func (q *qdrantRepository) keywordsSearchCollection(ctx context.Context, collectionName string,
+	params types.RetrieveParams, queryTokens []string, limit uint32,
+) []*types.IndexWithScore {
+	log := logger.GetLogger(ctx)
+	filter := q.getBaseFilter(params)
+
+	// Build should conditions for each token (OR logic)
+	// This allows matching documents that contain any of the query tokens
+	if len(queryTokens) > 0 {
+		shouldConditions := make([]*qdrant.Condition, 0, len(queryTokens))
+		for _, token := range queryTokens {
+			shouldConditions = append(shouldConditions, qdrant.NewMatchText(fieldContent, token))
+		}
+		filter.Should = shouldConditions
+	} else {
+		// Fallback to original query if tokenization fails
+		filter.Must = append(filter.Must, qdrant.NewMatchText(fieldContent, params.Query))
+	}
+
+	log.Debugf("[Qdrant] Searching in collection %s with %d should conditions", collectionName, len(filter.Should))
+
+	scrollResult, err := q.client.Scroll(ctx, &qdrant.ScrollPoints{
+		CollectionName: collectionName,
+		Filter:         filter,
+		Limit:          &limit,
+		WithPayload:    qdrant.NewWithPayload(true),
+	})
+	if err != nil {
+		log.Warnf("[Qdrant] Keywords search failed in %s: %v", collectionName, err)
+		return nil
+	}
+
+	log.Debugf("[Qdrant] Found %d results in collection %s", len(scrollResult), collectionName)
+
+	results := make([]*types.IndexWithScore, 0, len(scrollResult))
+	for _, point := range scrollResult {
+		payload := point.Payload
+		embedding := &QdrantVectorEmbeddingWithScore{
+			QdrantVectorEmbedding: QdrantVectorEmbedding{
+				Content:         payload[fieldContent].GetStringValue(),
+				SourceID:        payload[fieldSourceID].GetStringValue(),
+				SourceType:      int(payload[fieldSourceType].GetIntegerValue()),
+				ChunkID:         payload[fieldChunkID].GetStringValue(),
+				KnowledgeID:     payload[fieldKnowledgeID].GetStringValue(),
+				KnowledgeBaseID: payload[fieldKnowledgeBaseID].GetStringValue(),
+				TagID:           payload[fieldTagID].GetStringValue(),
+			},
+			Score: 1.0,
+		}
+
+		pointID := point.Id.GetUuid()
+		results = append(results, fromQdrantVectorEmbedding(pointID, embedding, types.MatchTypeKeywords))
+	}
+	return results
+}
+
 // CopyIndices copies index data from source knowledge base to target knowledge base
 func (q *qdrantRepository) CopyIndices(ctx context.Context,
 	sourceKnowledgeBaseID string,
@@ -832,7 +993,8 @@ func (q *qdrantRepository) CopyIndices(ctx context.Context,
 			}
 
 			newPoint := &qdrant.PointStruct{
-				Id:      qdrant.NewID(uuid.New().String()),
+				Id: qdrant.NewID(types.IndexRowID(targetChunkID, targetSourceID,
+					types.SourceType(payload[fieldSourceType].GetIntegerValue()))),
 				Vectors: vectors,
 				Payload: newPayload,
 			}
@@ -3,6 +3,7 @@ package qdrant
 import (
 	"sync"
 
+	"github.com/Tencent/WeKnora/internal/utils"
 	"github.com/qdrant/go-client/qdrant"
 )
 
@@ -13,6 +14,8 @@ type qdrantRepository struct {
 	replicationFactor  int // 0 = use Qdrant server default
 	// Cache for initialized collections (dimension -> true)
 	initializedCollections sync.Map
+	// Holds writes off a collection while it is re-counted and dropped as empty
+	dropGuard utils.DropGuard
 }
 
 type QdrantVectorEmbedding struct {
@@ -0,0 +1,218 @@
+// Package relational stores knowledge base graphs in the application
+// database, PostgreSQL or SQLite, so graph extraction and graph retrieval
+// work without a Neo4j deployment.
+package relational
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GraphRepository implements interfaces.RetrieveGraphRepository on adjacency
+// tables. Nodes are merged by knowledge base, knowledge and name, as the
+// Neo4j repository merges them by label and {name, kg}.
+type GraphRepository struct {
+	db *gorm.DB
+}
+
+// NewGraphRepository creates a new relational graph repository
+func NewGraphRepository(db *gorm.DB) interfaces.RetrieveGraphRepository {
+	return &GraphRepository{db: db}
+}
+
+// AddGraph adds graphs to the namespace, merging nodes that already exist
+func (r *GraphRepository) AddGraph(ctx context.Context, namespace types.NameSpace, graphs []*types.GraphData) error {
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, graph := range graphs {
+			for _, node := range graph.Node {
+				if err := r.mergeNode(tx, namespace, node.Name, node.Chunks, node.Attributes); err != nil {
+					return fmt.Errorf("failed to create node %s: %v", node.Name, err)
+				}
+			}
+			for _, rel := range graph.Relation {
+				// Relations create their endpoints, as apoc.merge.node does.
+				for _, name := range []string{rel.Node1, rel.Node2} {
+					if err := r.mergeNode(tx, namespace, name, nil, nil); err != nil {
+						return fmt.Errorf("failed to create node %s: %v", name, err)
+					}
+				}
+				err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&relationRow{
+					KnowledgeBaseID: namespace.KnowledgeBase,
+					KnowledgeID:     namespace.Knowledge,
+					Source:          rel.Node1,
+					Target:          rel.Node2,
+					Type:            rel.Type,
+				}).Error
+				if err != nil {
+					return fmt.Errorf("failed to create relationship: %v", err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Errorf(ctx, "failed to add graph: %v", err)
+		return err
+	}
+	return nil
+}
+
+// mergeNode creates the node or adds chunks and attributes to the stored one
+func (r *GraphRepository) mergeNode(
+	tx *gorm.DB, namespace types.NameSpace, name string, chunks []string, attributes []string,
+) error {
+	row := &nodeRow{
+		KnowledgeBaseID: namespace.KnowledgeBase,
+		KnowledgeID:     namespace.Knowledge,
+		Name:            name,
+		Attributes:      encodeStrings(attributes),
+		Chunks:          encodeStrings(chunks),
+	}
+	res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(row)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected > 0 || (len(chunks) == 0 && len(attributes) == 0) {
+		return nil
+	}
+
+	// Chunks of one knowledge are extracted concurrently; lock the row so
+	// two tasks merging the same entity do not drop each other's chunks.
+	query := tx.Where("knowledge_base_id = ? AND knowledge_id = ? AND name = ?",
+		namespace.KnowledgeBase, namespace.Knowledge, name)
+	if tx.Dialector.Name() == "postgres" {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var stored nodeRow
+	if err := query.First(&stored).Error; err != nil {
+		return err
+	}
+	return tx.Model(&nodeRow{}).
+		Where("knowledge_base_id = ? AND knowledge_id = ? AND name = ?",
+			namespace.KnowledgeBase, namespace.Knowledge, name).
+		Updates(map[string]interface{}{
+			"chunks":     encodeStrings(unionStrings(decodeStrings(stored.Chunks), chunks)),
+			"attributes": encodeStrings(unionStrings(decodeStrings(stored.Attributes), attributes)),
+		}).Error
+}
+
+// DelGraph deletes the graphs of the namespaces; a namespace without a
+// knowledge deletes the whole knowledge base graph
+func (r *GraphRepository) DelGraph(ctx context.Context, namespaces []types.NameSpace) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, namespace := range namespaces {
+			if namespace.KnowledgeBase == "" {
+				continue
+			}
+			if err := scope(tx, namespace).Delete(&relationRow{}).Error; err != nil {
+				return fmt.Errorf("failed to delete relationships: %v", err)
+			}
+			if err := scope(tx, namespace).Delete(&nodeRow{}).Error; err != nil {
+				return fmt.Errorf("failed to delete nodes: %v", err)
+			}
+		}
+		return nil
+	})
+}
+
+// SearchNode returns the relations of the nodes whose name contains any of
+// nodes, with the nodes on both ends
+func (r *GraphRepository) SearchNode(
+	ctx context.Context, namespace types.NameSpace, nodes []string,
+) (*types.GraphData, error) {
+	var conditions []string
+	var args []interface{}
+	for _, node := range nodes {
+		if node == "" {
+			continue
+		}
+		pattern := "%" + escapeLike(node) + "%"
+		conditions = append(conditions, `source LIKE ? ESCAPE '\' OR target LIKE ? ESCAPE '\'`)
+		args = append(args, pattern, pattern)
+	}
+	builder := newGraphBuilder()
+	if len(conditions) == 0 || namespace.KnowledgeBase == "" {
+		return builder.graph, nil
+	}
+
+	db := r.db.WithContext(ctx)
+	var relations []*relationRow
+	if err := scope(db, namespace).Where("("+strings.Join(conditions, " OR ")+")", args...).
+		Find(&relations).Error; err != nil {
+		logger.Errorf(ctx, "search node failed: %v", err)
+		return nil, err
+	}
+	if len(relations) == 0 {
+		return builder.graph, nil
+	}
+	names := make(map[string]bool)
+	for _, rel := range relations {
+		names[rel.Source] = true
+		names[rel.Target] = true
+	}
+	nameList := make([]string, 0, len(names))
+	for name := range names {
+		nameList = append(nameList, name)
+	}
+	var nodeRows []*nodeRow
+	if err := scope(db, namespace).Where("name IN ?", nameList).
+		Order("name").Find(&nodeRows).Error; err != nil {
+		logger.Errorf(ctx, "search node failed: %v", err)
+		return nil, err
+	}
+	for _, row := range nodeRows {
+		builder.addNode(row)
+	}
+	for _, rel := range relations {
+		builder.addRelation(rel)
+	}
+	return builder.graph, nil
+}
+
+// GetGraph returns every node and relation of the namespace
+func (r *GraphRepository) GetGraph(ctx context.Context, namespace types.NameSpace) (*types.GraphData, error) {
+	if namespace.KnowledgeBase == "" {
+		return nil, errors.New("knowledge base is required")
+	}
+	db := r.db.WithContext(ctx)
+	var nodeRows []*nodeRow
+	if err := scope(db, namespace).Order("name, knowledge_id").Find(&nodeRows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load nodes: %v", err)
+	}
+	var relations []*relationRow
+	if err := scope(db, namespace).Order("source, target, type").Find(&relations).Error; err != nil {
+		return nil, fmt.Errorf("failed to load relationships: %v", err)
+	}
+	builder := newGraphBuilder()
+	for _, row := range nodeRows {
+		builder.addNode(row)
+	}
+	for _, rel := range relations {
+		builder.addRelation(rel)
+	}
+	return builder.graph, nil
+}
+
+// scope restricts a query to the namespace
+func scope(db *gorm.DB, namespace types.NameSpace) *gorm.DB {
+	db = db.Where("knowledge_base_id = ?", namespace.KnowledgeBase)
+	if namespace.Knowledge != "" {
+		db = db.Where("knowledge_id = ?", namespace.Knowledge)
+	}
+	return db
+}
+
+// escapeLike escapes the LIKE wildcards of s for an ESCAPE '\' pattern
+func escapeLike(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "%", `\%`)
+	return strings.ReplaceAll(s, "_", `\_`)
+}
@@ -0,0 +1,143 @@
+package relational
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// graphTestDDL mirrors the knowledge base graph tables of the sqlite init
+// migration, inlined like the other sqlite repository tests.
+const graphTestDDL = `
+CREATE TABLE kb_graph_nodes (
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL DEFAULT '',
+    name VARCHAR(512) NOT NULL,
+    attributes TEXT NOT NULL DEFAULT '[]',
+    chunks TEXT NOT NULL DEFAULT '[]',
+    PRIMARY KEY (knowledge_base_id, knowledge_id, name)
+);
+CREATE TABLE kb_graph_relations (
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL DEFAULT '',
+    source VARCHAR(512) NOT NULL,
+    target VARCHAR(512) NOT NULL,
+    type VARCHAR(255) NOT NULL DEFAULT '',
+    PRIMARY KEY (knowledge_base_id, knowledge_id, source, target, type)
+);
+`
+
+func setupGraphRepo(t *testing.T) *GraphRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(graphTestDDL).Error)
+	return NewGraphRepository(db).(*GraphRepository)
+}
+
+func addGraph(t *testing.T, repo *GraphRepository, kb, knowledge, chunk string, relations ...[3]string) {
+	t.Helper()
+	graph := &types.GraphData{}
+	seen := map[string]bool{}
+	for _, rel := range relations {
+		graph.Relation = append(graph.Relation, &types.GraphRelation{Node1: rel[0], Type: rel[1], Node2: rel[2]})
+		for _, name := range []string{rel[0], rel[2]} {
+			if !seen[name] {
+				seen[name] = true
+				graph.Node = append(graph.Node, &types.GraphNode{
+					Name: name, Chunks: []string{chunk}, Attributes: []string{name + " from " + chunk},
+				})
+			}
+		}
+	}
+	require.NoError(t, repo.AddGraph(context.Background(),
+		types.NameSpace{KnowledgeBase: kb, Knowledge: knowledge}, []*types.GraphData{graph}))
+}
+
+func nodeByName(graph *types.GraphData, name string) *types.GraphNode {
+	for _, node := range graph.Node {
+		if node.Name == name {
+			return node
+		}
+	}
+	return nil
+}
+
+func TestAddGraphMergesNodes(t *testing.T) {
+	repo := setupGraphRepo(t)
+	ctx := context.Background()
+	addGraph(t, repo, "kb1", "k1", "c1", [3]string{"Alice", "WORKS_AT", "Acme"})
+	addGraph(t, repo, "kb1", "k1", "c2", [3]string{"Alice", "WORKS_AT", "Acme"}, [3]string{"Acme", "LOCATED_IN", "Berlin"})
+
+	graph, err := repo.GetGraph(ctx, types.NameSpace{KnowledgeBase: "kb1", Knowledge: "k1"})
+	require.NoError(t, err)
+	assert.Len(t, graph.Node, 3)
+	assert.Len(t, graph.Relation, 2)
+	alice := nodeByName(graph, "Alice")
+	require.NotNil(t, alice)
+	assert.ElementsMatch(t, []string{"c1", "c2"}, alice.Chunks)
+	assert.ElementsMatch(t, []string{"Alice from c1", "Alice from c2"}, alice.Attributes)
+}
+
+func TestGetGraphMergesKnowledge(t *testing.T) {
+	repo := setupGraphRepo(t)
+	ctx := context.Background()
+	addGraph(t, repo, "kb1", "k1", "c1", [3]string{"Alice", "WORKS_AT", "Acme"})
+	addGraph(t, repo, "kb1", "k2", "c9", [3]string{"Alice", "LIVES_IN", "Berlin"})
+	addGraph(t, repo, "kb2", "k3", "c5", [3]string{"Bob", "KNOWS", "Alice"})
+
+	graph, err := repo.GetGraph(ctx, types.NameSpace{KnowledgeBase: "kb1"})
+	require.NoError(t, err)
+	assert.Len(t, graph.Node, 3)
+	assert.Len(t, graph.Relation, 2)
+	assert.ElementsMatch(t, []string{"c1", "c9"}, nodeByName(graph, "Alice").Chunks)
+	assert.Nil(t, nodeByName(graph, "Bob"))
+}
+
+func TestSearchNode(t *testing.T) {
+	repo := setupGraphRepo(t)
+	ctx := context.Background()
+	addGraph(t, repo, "kb1", "k1", "c1",
+		[3]string{"Alice", "WORKS_AT", "Acme"}, [3]string{"Bob", "KNOWS", "Carol"}, [3]string{"50%_off", "APPLIES_TO", "Acme"})
+
+	graph, err := repo.SearchNode(ctx, types.NameSpace{KnowledgeBase: "kb1", Knowledge: "k1"}, []string{"lic"})
+	require.NoError(t, err)
+	require.Len(t, graph.Relation, 1)
+	assert.Equal(t, "Alice", graph.Relation[0].Node1)
+	assert.Equal(t, "Acme", graph.Relation[0].Node2)
+	assert.Len(t, graph.Node, 2)
+
+	// LIKE wildcards in the query match literally.
+	graph, err = repo.SearchNode(ctx, types.NameSpace{KnowledgeBase: "kb1"}, []string{"%_"})
+	require.NoError(t, err)
+	require.Len(t, graph.Relation, 1)
+	assert.Equal(t, "50%_off", graph.Relation[0].Node1)
+
+	graph, err = repo.SearchNode(ctx, types.NameSpace{KnowledgeBase: "kb1"}, []string{"nobody"})
+	require.NoError(t, err)
+	assert.Empty(t, graph.Relation)
+}
+
+func TestDelGraph(t *testing.T) {
+	repo := setupGraphRepo(t)
+	ctx := context.Background()
+	addGraph(t, repo, "kb1", "k1", "c1", [3]string{"Alice", "WORKS_AT", "Acme"})
+	addGraph(t, repo, "kb1", "k2", "c2", [3]string{"Bob", "KNOWS", "Carol"})
+
+	require.NoError(t, repo.DelGraph(ctx, []types.NameSpace{{KnowledgeBase: "kb1", Knowledge: "k1"}}))
+	graph, err := repo.GetGraph(ctx, types.NameSpace{KnowledgeBase: "kb1"})
+	require.NoError(t, err)
+	assert.Len(t, graph.Node, 2)
+	assert.Nil(t, nodeByName(graph, "Alice"))
+
+	require.NoError(t, repo.DelGraph(ctx, []types.NameSpace{{KnowledgeBase: "kb1"}}))
+	graph, err = repo.GetGraph(ctx, types.NameSpace{KnowledgeBase: "kb1"})
+	require.NoError(t, err)
+	assert.Empty(t, graph.Node)
+	assert.Empty(t, graph.Relation)
+}
@@ -0,0 +1,106 @@
+package relational
+
+import (
+	"encoding/json"
+	"slices"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// A knowledge base graph is stored as two adjacency tables keyed by knowledge
+// base, knowledge and entity name, mirroring the Neo4j labels and the
+// {name, kg} merge key. Chunk IDs and attributes are JSON arrays in TEXT
+// columns so the same schema works on PostgreSQL and SQLite.
+
+type nodeRow struct {
+	KnowledgeBaseID string `gorm:"column:knowledge_base_id;primaryKey"`
+	KnowledgeID     string `gorm:"column:knowledge_id;primaryKey"`
+	Name            string `gorm:"column:name;primaryKey"`
+	Attributes      string `gorm:"column:attributes"`
+	Chunks          string `gorm:"column:chunks"`
+}
+
+func (nodeRow) TableName() string { return "kb_graph_nodes" }
+
+type relationRow struct {
+	KnowledgeBaseID string `gorm:"column:knowledge_base_id;primaryKey"`
+	KnowledgeID     string `gorm:"column:knowledge_id;primaryKey"`
+	Source          string `gorm:"column:source;primaryKey"`
+	Target          string `gorm:"column:target;primaryKey"`
+	Type            string `gorm:"column:type;primaryKey"`
+}
+
+func (relationRow) TableName() string { return "kb_graph_relations" }
+
+// graphBuilder collects rows into a GraphData, merging nodes of the same
+// name and dropping repeated relations.
+type graphBuilder struct {
+	graph     *types.GraphData
+	nodes     map[string]*types.GraphNode
+	relations map[relationKey]bool
+}
+
+type relationKey struct {
+	source, target, relType string
+}
+
+func newGraphBuilder() *graphBuilder {
+	return &graphBuilder{
+		graph:     &types.GraphData{},
+		nodes:     make(map[string]*types.GraphNode),
+		relations: make(map[relationKey]bool),
+	}
+}
+
+func (b *graphBuilder) addNode(row *nodeRow) {
+	node, ok := b.nodes[row.Name]
+	if !ok {
+		node = &types.GraphNode{Name: row.Name, Chunks: []string{}, Attributes: []string{}}
+		b.nodes[row.Name] = node
+		b.graph.Node = append(b.graph.Node, node)
+	}
+	node.Chunks = unionStrings(node.Chunks, decodeStrings(row.Chunks))
+	node.Attributes = unionStrings(node.Attributes, decodeStrings(row.Attributes))
+}
+
+func (b *graphBuilder) addRelation(row *relationRow) {
+	key := relationKey{row.Source, row.Target, row.Type}
+	if b.relations[key] {
+		return
+	}
+	b.relations[key] = true
+	b.graph.Relation = append(b.graph.Relation, &types.GraphRelation{
+		Node1: row.Source,
+		Node2: row.Target,
+		Type:  row.Type,
+	})
+}
+
+func encodeStrings(values []string) string {
+	if values == nil {
+		values = []string{}
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
+
+func decodeStrings(data string) []string {
+	if data == "" {
+		return nil
+	}
+	var values []string
+	if err := json.Unmarshal([]byte(data), &values); err != nil {
+		return nil
+	}
+	return values
+}
+
+// unionStrings appends the values of extra missing from list
+func unionStrings(list []string, extra []string) []string {
+	for _, v := range extra {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
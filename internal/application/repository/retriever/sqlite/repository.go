@@ -2,7 +2,9 @@ package sqlite
 
 import (
 	"context"
+	"encoding/binary"
 	"fmt"
+	"math"
 	"strings"
 	"time"
 	"unicode"
@@ -141,6 +143,14 @@ func (r *sqliteRepository) Support() []types.RetrieverType {
 	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
 }
 
+// Capabilities reports SQLite features: field updates are plain UPDATE
+// statements.
+func (r *sqliteRepository) Capabilities() types.EngineCapabilities {
+	caps := types.NewEngineCapabilities(r.EngineType(), r.Support())
+	caps.PartialUpdate = true
+	return caps
+}
+
 func (r *sqliteRepository) Save(ctx context.Context, indexInfo *types.IndexInfo, params map[string]any) error {
 	row := toSQLiteEmbedding(indexInfo)
 	emb := extractEmbedding(params, indexInfo.SourceID)
@@ -212,6 +222,26 @@ func (r *sqliteRepository) DeleteByKnowledgeIDList(ctx context.Context, knowledg
 	return r.db.WithContext(ctx).Where("knowledge_id IN ?", knowledgeIDList).Delete(&sqliteEmbedding{}).Error
 }
 
+func (r *sqliteRepository) DeleteByKnowledgeBaseIDList(ctx context.Context, knowledgeBaseIDList []string, _ int, _ string) error {
+	if len(knowledgeBaseIDList) == 0 {
+		return nil
+	}
+	var rows []sqliteEmbedding
+	r.db.WithContext(ctx).Where("knowledge_base_id IN ?", knowledgeBaseIDList).Find(&rows)
+	r.deleteRowsAndVecs(ctx, rows)
+	return r.db.WithContext(ctx).Where("knowledge_base_id IN ?", knowledgeBaseIDList).Delete(&sqliteEmbedding{}).Error
+}
+
+func (r *sqliteRepository) DeleteByTagIDList(ctx context.Context, tagIDList []string, _ int, _ string) error {
+	if len(tagIDList) == 0 {
+		return nil
+	}
+	var rows []sqliteEmbedding
+	r.db.WithContext(ctx).Where("tag_id IN ?", tagIDList).Find(&rows)
+	r.deleteRowsAndVecs(ctx, rows)
+	return r.db.WithContext(ctx).Where("tag_id IN ?", tagIDList).Delete(&sqliteEmbedding{}).Error
+}
+
 func (r *sqliteRepository) CopyIndices(ctx context.Context,
 	_ string,
 	sourceToTargetKBIDMap map[string]string,
@@ -247,6 +277,73 @@ func (r *sqliteRepository) CopyIndices(ctx context.Context,
 	return nil
 }
 
+// ExportIndices streams the index rows of a knowledge base with their
+// vectors, batchSize rows at a time, in id order; see interfaces.IndexExporter.
+func (r *sqliteRepository) ExportIndices(ctx context.Context,
+	knowledgeBaseID string, batchSize int, fn func([]*types.ExportedIndex) error,
+) error {
+	var lastID uint
+	for {
+		var rows []sqliteEmbedding
+		if err := r.db.WithContext(ctx).
+			Where("knowledge_base_id = ? AND id > ?", knowledgeBaseID, lastID).
+			Order("id").
+			Limit(batchSize).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		batch := make([]*types.ExportedIndex, 0, len(rows))
+		for _, row := range rows {
+			batch = append(batch, &types.ExportedIndex{
+				SourceID:    row.SourceID,
+				SourceType:  types.SourceType(row.SourceType),
+				ChunkID:     row.ChunkID,
+				KnowledgeID: row.KnowledgeID,
+				TagID:       row.TagID,
+				Content:     row.Content,
+				IsEnabled:   row.IsEnabled == nil || *row.IsEnabled,
+				Embedding:   r.readVec(ctx, row.ID, row.Dimension),
+			})
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		lastID = rows[len(rows)-1].ID
+		if len(rows) < batchSize {
+			return nil
+		}
+	}
+}
+
+// MoveIndices reassigns the index rows of the given knowledge to another
+// knowledge base in one transaction; see interfaces.IndexMover. Vectors and
+// FTS rows are keyed by row id and stay untouched.
+func (r *sqliteRepository) MoveIndices(ctx context.Context,
+	knowledgeIDs []string, targetKnowledgeBaseID string, chunkTagMap map[string]string,
+) error {
+	if len(knowledgeIDs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&sqliteEmbedding{}).
+			Where("knowledge_id IN ?", knowledgeIDs).
+			Update("knowledge_base_id", targetKnowledgeBaseID).Error; err != nil {
+			return err
+		}
+		for chunkID, tagID := range chunkTagMap {
+			if err := tx.Model(&sqliteEmbedding{}).
+				Where("knowledge_id IN ? AND chunk_id = ?", knowledgeIDs, chunkID).
+				Update("tag_id", tagID).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (r *sqliteRepository) BatchUpdateChunkEnabledStatus(ctx context.Context, chunkStatusMap map[string]bool) error {
 	for chunkID, enabled := range chunkStatusMap {
 		r.db.WithContext(ctx).Model(&sqliteEmbedding{}).Where("chunk_id = ?", chunkID).Update("is_enabled", enabled)
@@ -504,6 +601,23 @@ func (r *sqliteRepository) insertVec(_ context.Context, rowID uint, dim int, emb
 	r.db.Exec(sql, rowID, blob)
 }
 
+// readVec returns the stored vector of a row, or nil when the row has none.
+func (r *sqliteRepository) readVec(_ context.Context, rowID uint, dim int) []float32 {
+	if dim <= 0 || !r.vecTables[dim] {
+		return nil
+	}
+	var blob []byte
+	r.db.Raw(fmt.Sprintf("SELECT embedding FROM %s WHERE rowid = ?", vecTableName(dim)), rowID).Row().Scan(&blob)
+	if len(blob) != dim*4 {
+		return nil
+	}
+	vec := make([]float32, dim)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(blob[i*4:]))
+	}
+	return vec
+}
+
 func (r *sqliteRepository) deleteRowsAndVecs(_ context.Context, rows []sqliteEmbedding) {
 	dimIDs := make(map[int][]uint)
 	for _, row := range rows {
@@ -570,6 +684,18 @@ func buildFilterWhere(params types.RetrieveParams) []whereClause {
 			args:   toInterfaceSlice(params.TagIDs),
 		})
 	}
+	if excluded := params.ExcludedKnowledgeIDs(); len(excluded) > 0 {
+		parts = append(parts, whereClause{
+			clause: "e.knowledge_id NOT IN (" + placeholders(len(excluded)) + ")",
+			args:   toInterfaceSlice(excluded),
+		})
+	}
+	if excludedChunks := params.ExcludedChunkIDs(); len(excludedChunks) > 0 {
+		parts = append(parts, whereClause{
+			clause: "e.chunk_id NOT IN (" + placeholders(len(excludedChunks)) + ")",
+			args:   toInterfaceSlice(excludedChunks),
+		})
+	}
 	return parts
 }
 
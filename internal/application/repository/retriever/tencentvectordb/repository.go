@@ -67,6 +67,14 @@ func (r *repository) Support() []types.RetrieverType {
 	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
 }
 
+// Capabilities reports Tencent VectorDB features: field updates go
+// through the collection's Update call.
+func (r *repository) Capabilities() types.EngineCapabilities {
+	caps := types.NewEngineCapabilities(r.EngineType(), r.Support())
+	caps.PartialUpdate = true
+	return caps
+}
+
 func (r *repository) Save(ctx context.Context, indexInfo *types.IndexInfo, params map[string]any) error {
 	return r.BatchSave(ctx, []*types.IndexInfo{indexInfo}, params)
 }
@@ -143,6 +151,28 @@ func (r *repository) DeleteByKnowledgeIDList(ctx context.Context, knowledgeIDLis
 	return r.deleteByFilter(ctx, dimension, tcvectordb.In(fieldKnowledgeID, knowledgeIDList))
 }
 
+func (r *repository) DeleteByKnowledgeBaseIDList(ctx context.Context, knowledgeBaseIDList []string, dimension int, knowledgeType string) error {
+	if len(knowledgeBaseIDList) == 0 {
+		return nil
+	}
+	if err := r.deleteByFilter(ctx, dimension, tcvectordb.In(fieldKnowledgeBaseID, knowledgeBaseIDList)); err != nil {
+		return err
+	}
+	r.dropCollectionIfEmpty(ctx, dimension)
+	return nil
+}
+
+func (r *repository) DeleteByTagIDList(ctx context.Context, tagIDList []string, dimension int, knowledgeType string) error {
+	if len(tagIDList) == 0 {
+		return nil
+	}
+	if err := r.deleteByFilter(ctx, dimension, tcvectordb.In(fieldTagID, tagIDList)); err != nil {
+		return err
+	}
+	r.dropCollectionIfEmpty(ctx, dimension)
+	return nil
+}
+
 func (r *repository) CopyIndices(
 	ctx context.Context,
 	sourceKnowledgeBaseID string,
@@ -467,6 +497,35 @@ func (r *repository) deleteByFilter(ctx context.Context, dimension int, cond str
 	return nil
 }
 
+// dropCollectionIfEmpty drops the per-dimension collection once its last
+// document is gone. An explicitly named collection (no dimension suffix) is
+// shared across dimensions and never dropped. Failures only skip the cleanup;
+// ensureCollection recreates the collection on the next write.
+func (r *repository) dropCollectionIfEmpty(ctx context.Context, dimension int) {
+	if !r.useDimensionSuffix {
+		return
+	}
+	log := logger.GetLogger(ctx)
+	collectionName := r.collectionName(dimension)
+	query, err := r.client.Database(r.databaseName).Collection(collectionName).Query(ctx, nil, &tcvectordb.QueryDocumentParams{
+		OutputFields: []string{fieldID},
+		Limit:        1,
+	})
+	if err != nil {
+		log.Warnf("[TencentVectorDB] skip empty collection cleanup of %s: %v", collectionName, err)
+		return
+	}
+	if len(query.Documents) > 0 {
+		return
+	}
+	if _, err := r.client.Database(r.databaseName).DropCollection(ctx, collectionName); err != nil {
+		log.Warnf("[TencentVectorDB] failed to drop empty collection %s: %v", collectionName, err)
+		return
+	}
+	r.initialized.Delete(dimension)
+	log.Infof("[TencentVectorDB] dropped empty collection %s", collectionName)
+}
+
 func (r *repository) updateChunkFields(ctx context.Context, chunkIDs []string, fields map[string]tcvectordb.Field) error {
 	collections, err := r.client.Database(r.databaseName).ListCollection(ctx)
 	if err != nil {
@@ -517,11 +576,11 @@ func (r *repository) baseFilter(params types.RetrieveParams) *tcvectordb.Filter
 	if len(params.TagIDs) > 0 {
 		conditions = append(conditions, tcvectordb.In(fieldTagID, params.TagIDs))
 	}
-	if len(params.ExcludeKnowledgeIDs) > 0 {
-		conditions = append(conditions, tcvectordb.NotIn(fieldKnowledgeID, params.ExcludeKnowledgeIDs))
+	if excluded := params.ExcludedKnowledgeIDs(); len(excluded) > 0 {
+		conditions = append(conditions, tcvectordb.NotIn(fieldKnowledgeID, excluded))
 	}
-	if len(params.ExcludeChunkIDs) > 0 {
-		conditions = append(conditions, tcvectordb.NotIn(fieldChunkID, params.ExcludeChunkIDs))
+	if excludedChunks := params.ExcludedChunkIDs(); len(excludedChunks) > 0 {
+		conditions = append(conditions, tcvectordb.NotIn(fieldChunkID, excludedChunks))
 	}
 	return tcvectordb.NewFilter(strings.Join(conditions, " and "))
 }
@@ -6,11 +6,13 @@ import (
 	"maps"
 	"slices"
 	"strings"
+	"sync/atomic"
 	"unicode/utf8"
 
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
 	"github.com/go-openapi/strfmt"
 	"github.com/google/uuid"
 	"github.com/weaviate/weaviate-go-client/v5/weaviate"
@@ -32,6 +34,9 @@ const (
 	fieldEmbedding        = "embedding"
 	fieldIsEnabled        = "is_enabled"
 	fieldID               = "id"
+	// collectionConcurrency bounds how many dimension collections one call
+	// works on at the same time.
+	collectionConcurrency = 4
 )
 
 // NewWeaviateRetrieveEngineRepository creates and initializes a new Weaviate repository.
@@ -167,6 +172,14 @@ func (w *weaviateRepository) Support() []types.RetrieverType {
 	return []types.RetrieverType{types.KeywordsRetrieverType, types.VectorRetrieverType}
 }
 
+// Capabilities reports Weaviate features. Searches are capped by the
+// server's QUERY_MAXIMUM_RESULTS, 10000 unless reconfigured.
+func (w *weaviateRepository) Capabilities() types.EngineCapabilities {
+	caps := types.NewEngineCapabilities(w.EngineType(), w.Support())
+	caps.MaxTopK = 10000
+	return caps
+}
+
 // EstimateStorageSize calculates the estimated storage size for a list of indices
 func (w *weaviateRepository) EstimateStorageSize(ctx context.Context,
 	indexInfoList []*types.IndexInfo, params map[string]any,
@@ -197,21 +210,20 @@ func (w *weaviateRepository) Save(ctx context.Context,
 	}
 
 	dimension := len(embeddingDB.Embedding)
-	if err := w.ensureCollection(ctx, dimension); err != nil {
-		return err
-	}
 	collectionName := w.getCollectionName(dimension)
 	dataSchema := createPayload(embeddingDB)
 
 	id := embedding.ChunkID
 	// Create point in Weaviate
-	_, err := w.client.Data().Creator().
-		WithClassName(collectionName).
-		WithID(id).
-		WithProperties(dataSchema).
-		WithVector(embeddingDB.Embedding).
-		Do(ctx)
-
+	err := w.writeCollection(ctx, dimension, func() error {
+		_, err := w.client.Data().Creator().
+			WithClassName(collectionName).
+			WithID(id).
+			WithProperties(dataSchema).
+			WithVector(embeddingDB.Embedding).
+			Do(ctx)
+		return err
+	})
 	if err != nil {
 		log.Errorf("[Weaviate] Failed to save index: %v", err)
 		return err
@@ -252,37 +264,84 @@ func (w *weaviateRepository) BatchSave(ctx context.Context,
 		return nil
 	}
 
-	// Save points to each dimension-specific collection
-	totalSaved := 0
-	for dimension, embeddings := range embeddingsByDimension {
-		batcher := w.client.Batch().ObjectsBatcher()
+	// Save points to the dimension-specific collections concurrently. A
+	// failing collection does not stop the others; all failures are reported.
+	var totalSaved atomic.Int64
+	err := utils.ForEachLimit(slices.Sorted(maps.Keys(embeddingsByDimension)), collectionConcurrency,
+		func(_ int, dimension int) error {
+			embeddings := embeddingsByDimension[dimension]
+			if err := w.batchSaveCollection(ctx, dimension, embeddings, additionalParams); err != nil {
+				return err
+			}
+			totalSaved.Add(int64(len(embeddings)))
+			return nil
+		})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("[Weaviate] Successfully batch saved %d indices", totalSaved.Load())
+	return nil
+}
+
+// batchSaveCollection writes embeddings to the collection of the given
+// dimension, creating it first if needed.
+func (w *weaviateRepository) batchSaveCollection(ctx context.Context, dimension int,
+	embeddings []*types.IndexInfo, additionalParams map[string]any,
+) error {
+	log := logger.GetLogger(ctx)
+	collectionName := w.getCollectionName(dimension)
+	objects := make([]*models.Object, 0, len(embeddings))
+	for _, embedding := range embeddings {
+		embeddingDB := toWeaviateVectorEmbedding(embedding, additionalParams)
+		dataSchema := createPayload(embeddingDB)
+
+		obj := &models.Object{
+			Class:      collectionName,
+			ID:         strfmt.UUID(embeddingDB.ChunkID),
+			Properties: dataSchema,
+			Vector:     embeddingDB.Embedding,
+		}
+		objects = append(objects, obj)
+	}
+	// Flush batch
+	err := w.writeCollection(ctx, dimension, func() error {
+		_, err := w.client.Batch().ObjectsBatcher().WithObjects(objects...).Do(ctx)
+		return err
+	})
+	if err != nil {
+		log.Errorf("[Weaviate] Failed to execute batch operation for dimension %d: %v", dimension, err)
+		return fmt.Errorf("failed to batch save (dimension %d): %w", dimension, err)
+	}
+	log.Infof("[Weaviate] Saved %d points to collection %s", len(embeddings), collectionName)
+	return nil
+}
+
+// writeCollection runs write against the class of the dimension, creating
+// it first if needed, while this process cannot drop it as empty. A class
+// that another instance dropped after this one cached it is recreated and
+// the write retried once.
+func (w *weaviateRepository) writeCollection(ctx context.Context, dimension int, write func() error) error {
+	collectionName := w.getCollectionName(dimension)
+	return w.dropGuard.Write(collectionName, func() error {
 		if err := w.ensureCollection(ctx, dimension); err != nil {
 			return err
 		}
-		collectionName := w.getCollectionName(dimension)
-		for _, embedding := range embeddings {
-			embeddingDB := toWeaviateVectorEmbedding(embedding, additionalParams)
-			dataSchema := createPayload(embeddingDB)
-
-			obj := &models.Object{
-				Class:      collectionName,
-				ID:         strfmt.UUID(embeddingDB.ChunkID),
-				Properties: dataSchema,
-				Vector:     embeddingDB.Embedding,
-			}
-			batcher.WithObjects(obj)
+		err := write()
+		if err == nil {
+			return nil
 		}
-		// Flush batch
-		if _, err := batcher.Do(ctx); err != nil {
-			log.Errorf("[Weaviate] Failed to execute batch operation for dimension %d: %v", dimension, err)
-			return fmt.Errorf("failed to batch save (dimension %d): %w", dimension, err)
+		exists, existsErr := w.client.Schema().ClassExistenceChecker().WithClassName(collectionName).Do(ctx)
+		if existsErr != nil || exists {
+			return err
 		}
-		totalSaved += len(embeddings)
-		log.Infof("[Weaviate] Saved %d points to collection %s", len(embeddings), collectionName)
-	}
-
-	log.Infof("[Weaviate] Successfully batch saved %d indices", totalSaved)
-	return nil
+		logger.GetLogger(ctx).Warnf("[Weaviate] Collection %s was dropped elsewhere, recreating it", collectionName)
+		w.initializedCollections.Delete(dimension)
+		if err := w.ensureCollection(ctx, dimension); err != nil {
+			return err
+		}
+		return write()
+	})
 }
 
 // DeleteByChunkIDList removes points from the collection based on chunk IDs
@@ -375,6 +434,111 @@ func (w *weaviateRepository) DeleteBySourceIDList(ctx context.Context,
 	return nil
 }
 
+// DeleteByKnowledgeBaseIDList removes every object of the given knowledge
+// bases and drops the collection of the dimension if it is left empty
+func (w *weaviateRepository) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	if len(knowledgeBaseIDList) == 0 {
+		logger.GetLogger(ctx).Warn("[Weaviate] Empty knowledge base ID list provided for deletion, skipping")
+		return nil
+	}
+	return w.deleteByField(ctx, fieldKnowledgeBaseID, knowledgeBaseIDList, dimension)
+}
+
+// DeleteByTagIDList removes objects based on tag IDs and drops the collection
+// of the dimension if it is left empty
+func (w *weaviateRepository) DeleteByTagIDList(ctx context.Context,
+	tagIDList []string, dimension int, knowledgeType string,
+) error {
+	if len(tagIDList) == 0 {
+		logger.GetLogger(ctx).Warn("[Weaviate] Empty tag ID list provided for deletion, skipping")
+		return nil
+	}
+	return w.deleteByField(ctx, fieldTagID, tagIDList, dimension)
+}
+
+// deleteByField deletes the objects whose field matches one of values and
+// drops the per-dimension class once it holds no objects. The objects are
+// counted again with this process's writes to the class held off, and the
+// class is evicted from the cache before they resume, so a concurrent write
+// either lands first and keeps the class or recreates it through
+// ensureCollection.
+func (w *weaviateRepository) deleteByField(ctx context.Context,
+	field string, values []string, dimension int,
+) error {
+	log := logger.GetLogger(ctx)
+	collectionName := w.getCollectionName(dimension)
+	exists, err := w.client.Schema().ClassExistenceChecker().WithClassName(collectionName).Do(ctx)
+	if err != nil {
+		log.Errorf("[Weaviate] Failed to check collection existence: %v", err)
+		return fmt.Errorf("failed to check collection existence: %w", err)
+	}
+	if !exists {
+		return nil
+	}
+
+	log.Infof("[Weaviate] Deleting indices by %s from %s, count: %d", field, collectionName, len(values))
+	_, err = w.client.Batch().ObjectsBatchDeleter().
+		WithClassName(collectionName).
+		WithWhere(filters.Where().
+			WithPath([]string{field}).
+			WithOperator(filters.ContainsAny).
+			WithValueText(values...)).
+		WithOutput("minimal").
+		Do(ctx)
+	if err != nil {
+		log.Errorf("[Weaviate] Failed to delete by %s: %v", field, err)
+		return fmt.Errorf("failed to delete by %s: %w", field, err)
+	}
+
+	dropped, err := w.dropGuard.DropIfEmpty(collectionName,
+		func() (int, error) { return w.countObjects(ctx, collectionName) },
+		func() error {
+			if err := w.client.Schema().ClassDeleter().WithClassName(collectionName).Do(ctx); err != nil {
+				return err
+			}
+			w.initializedCollections.Delete(dimension)
+			return nil
+		})
+	if err != nil {
+		// The delete succeeded; a failed count or drop only skips the cleanup.
+		log.Warnf("[Weaviate] Skipping empty collection cleanup of %s: %v", collectionName, err)
+	} else if dropped {
+		log.Infof("[Weaviate] Dropped empty collection %s", collectionName)
+	}
+
+	log.Infof("[Weaviate] Successfully deleted documents by %s", field)
+	return nil
+}
+
+// countObjects returns the number of objects in a class via an Aggregate
+// meta count.
+func (w *weaviateRepository) countObjects(ctx context.Context, collectionName string) (int, error) {
+	result, err := w.client.GraphQL().Aggregate().
+		WithClassName(collectionName).
+		WithFields(graphql.Field{Name: "meta", Fields: []graphql.Field{{Name: "count"}}}).
+		Do(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if len(result.Errors) > 0 {
+		return 0, fmt.Errorf("graphql aggregate failed: %s", result.Errors[0].Message)
+	}
+	aggregate, _ := result.Data["Aggregate"].(map[string]interface{})
+	groups, _ := aggregate[collectionName].([]interface{})
+	if len(groups) == 0 {
+		return 0, fmt.Errorf("empty aggregate result")
+	}
+	group, _ := groups[0].(map[string]interface{})
+	meta, _ := group["meta"].(map[string]interface{})
+	count, ok := meta["count"].(float64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected aggregate result")
+	}
+	return int(count), nil
+}
+
 // BatchUpdateChunkEnabledStatus updates the enabled status of chunks in batch
 func (w *weaviateRepository) BatchUpdateChunkEnabledStatus(ctx context.Context, chunkStatusMap map[string]bool) error {
 	log := logger.GetLogger(ctx)
@@ -392,39 +556,49 @@ func (w *weaviateRepository) BatchUpdateChunkEnabledStatus(ctx context.Context,
 		return fmt.Errorf("failed to list collections: %w", err)
 	}
 
-	// Update in all matching collections
+	// Update in all matching collections concurrently
+	_ = utils.ForEachLimit(w.ownCollections(collections), collectionConcurrency, func(_ int, collectionName string) error {
+		w.updateEnabledStatusInCollection(ctx, collectionName, chunkStatusMap)
+		return nil
+	})
+	log.Infof("[Weaviate] Batch update chunk enabled status completed")
+	return nil
+}
+
+// ownCollections keeps the collections named after this repository's base
+// name, i.e. the ones holding its embeddings.
+func (w *weaviateRepository) ownCollections(collections []string) []string {
+	own := make([]string, 0, len(collections))
 	for _, collectionName := range collections {
-		// Only process collections that start with our base name
-		if len(collectionName) <= len(w.collectionBaseName) ||
-			collectionName[:len(w.collectionBaseName)] != w.collectionBaseName {
-			continue
+		if len(collectionName) > len(w.collectionBaseName) && strings.HasPrefix(collectionName, w.collectionBaseName) {
+			own = append(own, collectionName)
 		}
-		for chunkID, enabled := range chunkStatusMap {
-			if err != nil {
-				log.Errorf("[Weaviate] Failed to search ID by chunk ID %s in %s: %v", chunkID, collectionName, err)
-				continue
-			}
-			err = w.client.Data().Updater().
-				WithClassName(collectionName).
-				WithID(chunkID).
-				WithProperties(map[string]interface{}{
-					fieldIsEnabled: enabled,
-				}).
-				Do(ctx)
+	}
+	return own
+}
 
+// updateEnabledStatusInCollection updates the is_enabled property of the
+// given chunks in one collection. Failures are logged per chunk.
+func (w *weaviateRepository) updateEnabledStatusInCollection(
+	ctx context.Context, collectionName string, chunkStatusMap map[string]bool,
+) {
+	log := logger.GetLogger(ctx)
+	for chunkID, enabled := range chunkStatusMap {
+		err := w.client.Data().Updater().
+			WithClassName(collectionName).
+			WithID(chunkID).
+			WithProperties(map[string]interface{}{
+				fieldIsEnabled: enabled,
+			}).
+			Do(ctx)
+		if err != nil {
 			isEnabled := "enabled"
 			if !enabled {
 				isEnabled = "disabled"
 			}
-
-			if err != nil {
-				log.Errorf("[Weaviate] Failed to update chunk %s status in %s: %v", isEnabled, collectionName, err)
-				continue
-			}
+			log.Errorf("[Weaviate] Failed to update chunk %s status in %s: %v", isEnabled, collectionName, err)
 		}
 	}
-	log.Infof("[Weaviate] Batch update chunk enabled status completed")
-	return nil
 }
 
 // BatchUpdateChunkTagID updates the tag ID of chunks in batch
@@ -500,17 +674,17 @@ func (w *weaviateRepository) getBaseFilter(params types.RetrieveParams) *filters
 			WithOperator(filters.ContainsAny).
 			WithValueText(params.TagIDs...))
 	}
-	if len(params.ExcludeKnowledgeIDs) > 0 {
+	if excluded := params.ExcludedKnowledgeIDs(); len(excluded) > 0 {
 		operands = append(operands, filters.Where().
 			WithPath([]string{fieldKnowledgeID}).
 			WithOperator(filters.NotEqual).
-			WithValueText(params.ExcludeKnowledgeIDs...))
+			WithValueText(excluded...))
 	}
-	if len(params.ExcludeChunkIDs) > 0 {
+	if excludedChunks := params.ExcludedChunkIDs(); len(excludedChunks) > 0 {
 		operands = append(operands, filters.Where().
 			WithPath([]string{fieldChunkID}).
 			WithOperator(filters.NotEqual).
-			WithValueText(params.ExcludeChunkIDs...))
+			WithValueText(excludedChunks...))
 	}
 
 	return filters.Where().
@@ -615,48 +789,21 @@ func (w *weaviateRepository) KeywordsRetrieve(ctx context.Context,
 		return nil, fmt.Errorf("failed to list collections: %w", err)
 	}
 
+	// Search all matching collections concurrently; each call owns its slot
+	// so results keep the collection order. Any failing collection fails
+	// the retrieval, as before.
+	collections = w.ownCollections(collections)
+	resultsByCollection := make([][]*types.IndexWithScore, len(collections))
+	err = utils.ForEachLimit(collections, collectionConcurrency, func(i int, collectionName string) error {
+		results, err := w.keywordsSearchCollection(ctx, collectionName, params)
+		resultsByCollection[i] = results
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
 	var allResults []*types.IndexWithScore
-
-	for _, collectionName := range collections {
-		log.Debugf("[Weaviate] Checking collection: %s", collectionName)
-		// Only process collections that start with our base name
-		if len(collectionName) <= len(w.collectionBaseName) ||
-			collectionName[:len(w.collectionBaseName)] != w.collectionBaseName {
-			log.Debugf("[Weaviate] Skipping collection %s (doesn't match base name %s)", collectionName, w.collectionBaseName)
-			continue
-		}
-
-		filter := w.getBaseFilter(params)
-
-		//bm25 search
-		bm25 := w.client.GraphQL().Bm25ArgBuilder().
-			WithQuery(params.Query).
-			WithProperties([]string{fieldContent}...)
-
-		fields := getKeywordsFields()
-
-		result, err := w.client.GraphQL().Get().WithClassName(collectionName).
-			WithWhere(filter).
-			WithLimit(params.TopK).
-			WithFields(fields...).
-			WithBM25(bm25).
-			Do(ctx)
-
-		if err != nil {
-			log.Errorf("[Weaviate] keywords search failed: %v", err)
-			return nil, fmt.Errorf("failed to search: %w", err)
-		}
-		if len(result.Errors) > 0 {
-			log.Errorf("[Weaviate] keywords search failed: %v", result.Errors)
-			return nil, fmt.Errorf("graphql search failed: %s", result.Errors[0].Message)
-		}
-		data, ok := result.Data["Get"].(map[string]interface{})
-		if !ok || data[collectionName] == nil {
-			log.Warnf("[Weaviate] No keywords matches found that meet threshold %.4f", params.Threshold)
-			continue
-		}
-		items := data[collectionName].([]interface{})
-		results := parseGraphQLResponse(items, collectionName, types.MatchTypeKeywords)
+	for _, results := range resultsByCollection {
 		allResults = append(allResults, results...)
 	}
 
@@ -673,6 +820,44 @@ func (w *weaviateRepository) KeywordsRetrieve(ctx context.Context,
 	return buildRetrieveResult(allResults, types.KeywordsRetrieverType), nil
 }
 
+// keywordsSearchCollection runs the BM25 search in one collection.
+func (w *weaviateRepository) keywordsSearchCollection(ctx context.Context,
+	collectionName string, params types.RetrieveParams,
+) ([]*types.IndexWithScore, error) {
+	log := logger.GetLogger(ctx)
+	filter := w.getBaseFilter(params)
+
+	//bm25 search
+	bm25 := w.client.GraphQL().Bm25ArgBuilder().
+		WithQuery(params.Query).
+		WithProperties([]string{fieldContent}...)
+
+	fields := getKeywordsFields()
+
+	result, err := w.client.GraphQL().Get().WithClassName(collectionName).
+		WithWhere(filter).
+		WithLimit(params.TopK).
+		WithFields(fields...).
+		WithBM25(bm25).
+		Do(ctx)
+
+	if err != nil {
+		log.Errorf("[Weaviate] keywords search failed: %v", err)
+		return nil, fmt.Errorf("failed to search %s: %w", collectionName, err)
+	}
+	if len(result.Errors) > 0 {
+		log.Errorf("[Weaviate] keywords search failed: %v", result.Errors)
+		return nil, fmt.Errorf("graphql search failed in %s: %s", collectionName, result.Errors[0].Message)
+	}
+	data, ok := result.Data["Get"].(map[string]interface{})
+	if !ok || data[collectionName] == nil {
+		log.Warnf("[Weaviate] No keywords matches found that meet threshold %.4f", params.Threshold)
+		return nil, nil
+	}
+	items := data[collectionName].([]interface{})
+	return parseGraphQLResponse(items, collectionName, types.MatchTypeKeywords), nil
+}
+
 // CopyIndices copies index data from source knowledge base to target knowledge base
 func (w *weaviateRepository) CopyIndices(ctx context.Context,
 	sourceKnowledgeBaseID string,
@@ -3,6 +3,7 @@ package weaviate
 import (
 	"sync"
 
+	"github.com/Tencent/WeKnora/internal/utils"
 	"github.com/weaviate/weaviate-go-client/v5/weaviate"
 )
 
@@ -13,6 +14,8 @@ type weaviateRepository struct {
 	desiredShardCount  int // 0 = use Weaviate server default
 	// Cache for initialized collections (dimension -> true)
 	initializedCollections sync.Map
+	// Holds writes off a collection while it is re-counted and dropped as empty
+	dropGuard utils.DropGuard
 }
 
 type WeaviateVectorEmbedding struct {
@@ -252,14 +252,38 @@ func (r *sessionRepository) SetPinned(
 // Update updates a session
 func (r *sessionRepository) Update(ctx context.Context, session *types.Session, userID string) (int64, error) {
 	session.UpdatedAt = time.Now()
+	updates := map[string]interface{}{
+		"title":       session.Title,
+		"description": session.Description,
+		"updated_at":  session.UpdatedAt,
+	}
+	// Older clients only send title and description; keep the memory
+	// policy, rerank config and pipeline config unless new ones are given.
+	if session.MemoryPolicy != nil {
+		policy, err := session.MemoryPolicy.Value()
+		if err != nil {
+			return 0, err
+		}
+		updates["memory_policy"] = policy
+	}
+	if session.RerankConfig != nil {
+		rerankConfig, err := session.RerankConfig.Value()
+		if err != nil {
+			return 0, err
+		}
+		updates["rerank_config"] = rerankConfig
+	}
+	if session.PipelineConfig != nil {
+		pipelineConfig, err := session.PipelineConfig.Value()
+		if err != nil {
+			return 0, err
+		}
+		updates["pipeline_config"] = pipelineConfig
+	}
 	res := applySessionUserScope(r.db.WithContext(ctx).
 		Model(&types.Session{}).
 		Where("tenant_id = ? AND id = ?", session.TenantID, session.ID), userID).
-		Updates(map[string]interface{}{
-			"title":       session.Title,
-			"description": session.Description,
-			"updated_at":  session.UpdatedAt,
-		})
+		Updates(updates)
 	return res.RowsAffected, res.Error
 }
 
@@ -113,6 +113,34 @@ func TestSessionRepositoryUpdateHonorsUserScope(t *testing.T) {
 	require.Equal(t, "alice updated session", changed.Title)
 }
 
+func TestSessionRepositoryUpdateKeepsPipelineConfigWhenOmitted(t *testing.T) {
+	repo, db := newSessionRepositoryForTest(t)
+	ctx := context.Background()
+	session := createSessionForTest(t, db, 1, "alice")
+
+	rounds := 2
+	_, err := repo.Update(ctx, &types.Session{
+		ID:             session.ID,
+		TenantID:       session.TenantID,
+		Title:          session.Title,
+		PipelineConfig: &types.PipelineConfig{History: &types.PipelineHistoryConfig{MaxRounds: &rounds}},
+	}, "alice")
+	require.NoError(t, err)
+
+	_, err = repo.Update(ctx, &types.Session{
+		ID:       session.ID,
+		TenantID: session.TenantID,
+		Title:    "renamed",
+	}, "alice")
+	require.NoError(t, err)
+
+	got, err := repo.Get(ctx, 1, "alice", session.ID)
+	require.NoError(t, err)
+	require.Equal(t, "renamed", got.Title)
+	require.NotNil(t, got.PipelineConfig)
+	require.Equal(t, 2, *got.PipelineConfig.History.MaxRounds)
+}
+
 func TestSessionRepositoryDeleteHonorsUserScope(t *testing.T) {
 	repo, db := newSessionRepositoryForTest(t)
 	ctx := context.Background()
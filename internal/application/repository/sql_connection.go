@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+)
+
+// sqlConnectionRepository implements the SQLConnectionRepository interface
+type sqlConnectionRepository struct {
+	db *gorm.DB
+}
+
+// NewSQLConnectionRepository creates a new SQL connection repository
+func NewSQLConnectionRepository(db *gorm.DB) interfaces.SQLConnectionRepository {
+	return &sqlConnectionRepository{db: db}
+}
+
+// Create creates a new SQL connection
+func (r *sqlConnectionRepository) Create(ctx context.Context, conn *types.SQLConnection) error {
+	return r.db.WithContext(ctx).Create(conn).Error
+}
+
+// GetByID retrieves a SQL connection by ID within a tenant scope
+func (r *sqlConnectionRepository) GetByID(ctx context.Context, tenantID uint64, id string) (*types.SQLConnection, error) {
+	var conn types.SQLConnection
+	if err := r.db.WithContext(ctx).Where(
+		"id = ? AND tenant_id = ?", id, tenantID,
+	).First(&conn).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &conn, nil
+}
+
+// List lists all SQL connections for a tenant
+func (r *sqlConnectionRepository) List(ctx context.Context, tenantID uint64) ([]*types.SQLConnection, error) {
+	var conns []*types.SQLConnection
+	if err := r.db.WithContext(ctx).Where(
+		"tenant_id = ?", tenantID,
+	).Order("created_at ASC").Find(&conns).Error; err != nil {
+		return nil, err
+	}
+	return conns, nil
+}
+
+// Update updates a SQL connection
+func (r *sqlConnectionRepository) Update(ctx context.Context, conn *types.SQLConnection) error {
+	return r.db.WithContext(ctx).Model(&types.SQLConnection{}).Where(
+		"id = ? AND tenant_id = ?", conn.ID, conn.TenantID,
+	).Select("*").Updates(conn).Error
+}
+
+// Delete soft-deletes a SQL connection
+func (r *sqlConnectionRepository) Delete(ctx context.Context, tenantID uint64, id string) error {
+	return r.db.WithContext(ctx).Where(
+		"id = ? AND tenant_id = ?", id, tenantID,
+	).Delete(&types.SQLConnection{}).Error
+}
@@ -135,6 +135,31 @@ func (r *knowledgeTagRepository) ListByKB(
 	return tags, total, nil
 }
 
+// ListAllByKB lists every tag of a knowledge base, for resolving the tag hierarchy.
+func (r *knowledgeTagRepository) ListAllByKB(ctx context.Context, tenantID uint64, kbID string) ([]*types.KnowledgeTag, error) {
+	var tags []*types.KnowledgeTag
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
+		Order("sort_order ASC, created_at DESC, seq_id DESC").
+		Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// ReparentChildren moves the child tags of parentID under newParentID.
+func (r *knowledgeTagRepository) ReparentChildren(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	parentID string,
+	newParentID string,
+) error {
+	return r.db.WithContext(ctx).Model(&types.KnowledgeTag{}).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND parent_id = ?", tenantID, kbID, parentID).
+		Update("parent_id", newParentID).Error
+}
+
 // Delete deletes a knowledge tag
 func (r *knowledgeTagRepository) Delete(ctx context.Context, tenantID uint64, id string) error {
 	return r.db.WithContext(ctx).
@@ -232,6 +257,8 @@ func (r *knowledgeTagRepository) DeleteUnusedTags(ctx context.Context, tenantID
 		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
 		Where("id NOT IN (SELECT DISTINCT ktr.tag_id FROM knowledge_tag_relations ktr JOIN knowledges k ON ktr.knowledge_id = k.id AND k.deleted_at IS NULL AND k.tenant_id = ? AND k.knowledge_base_id = ?)", tenantID, kbID).
 		Where("id NOT IN (SELECT DISTINCT tag_id FROM chunks WHERE tenant_id = ? AND knowledge_base_id = ? AND tag_id IS NOT NULL AND tag_id != '' AND deleted_at IS NULL)", tenantID, kbID).
+		// Parent tags group their children and are kept even when unused themselves.
+		Where("id NOT IN (SELECT DISTINCT parent_id FROM knowledge_tags WHERE tenant_id = ? AND knowledge_base_id = ? AND parent_id IS NOT NULL AND parent_id != '')", tenantID, kbID).
 		Delete(&types.KnowledgeTag{})
 	return result.RowsAffected, result.Error
 }
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tagAccessRuleRepository implements the TagAccessRuleRepository interface
+type tagAccessRuleRepository struct {
+	db *gorm.DB
+}
+
+// NewTagAccessRuleRepository creates a new tag access rule repository
+func NewTagAccessRuleRepository(db *gorm.DB) interfaces.TagAccessRuleRepository {
+	return &tagAccessRuleRepository{db: db}
+}
+
+// ListByKB lists the rules of a knowledge base
+func (r *tagAccessRuleRepository) ListByKB(
+	ctx context.Context, tenantID uint64, kbID string,
+) ([]*types.TagAccessRule, error) {
+	var rules []*types.TagAccessRule
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ?", tenantID, kbID).
+		Order("subject_type ASC, subject_id ASC").
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// ListForSubjects lists the rules of any of the subjects on any of the knowledge bases
+func (r *tagAccessRuleRepository) ListForSubjects(
+	ctx context.Context,
+	tenantID uint64,
+	kbIDs []string,
+	subjects []types.TagAccessSubject,
+) ([]*types.TagAccessRule, error) {
+	if len(kbIDs) == 0 || len(subjects) == 0 {
+		return nil, nil
+	}
+	subjectQuery := r.db.WithContext(ctx)
+	for i, subject := range subjects {
+		if i == 0 {
+			subjectQuery = subjectQuery.Where("subject_type = ? AND subject_id = ?", subject.Type, subject.ID)
+		} else {
+			subjectQuery = subjectQuery.Or("subject_type = ? AND subject_id = ?", subject.Type, subject.ID)
+		}
+	}
+	var rules []*types.TagAccessRule
+	if err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id IN ?", tenantID, kbIDs).
+		Where(subjectQuery).
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Save creates the rule, or replaces the tags of the subject's existing rule
+func (r *tagAccessRuleRepository) Save(ctx context.Context, rule *types.TagAccessRule) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "tenant_id"}, {Name: "knowledge_base_id"}, {Name: "subject_type"}, {Name: "subject_id"},
+		},
+		DoUpdates: clause.AssignmentColumns([]string{"tag_ids", "updated_at"}),
+	}).Create(rule).Error
+}
+
+// Delete deletes a rule of a knowledge base
+func (r *tagAccessRuleRepository) Delete(ctx context.Context, tenantID uint64, kbID string, id string) error {
+	result := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND knowledge_base_id = ? AND id = ?", tenantID, kbID, id).
+		Delete(&types.TagAccessRule{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+const tagAccessRuleTestDDL = `
+CREATE TABLE tag_access_rules (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    subject_type VARCHAR(16) NOT NULL,
+    subject_id VARCHAR(64) NOT NULL DEFAULT '',
+    tag_ids TEXT,
+    created_at DATETIME,
+    updated_at DATETIME,
+    UNIQUE (tenant_id, knowledge_base_id, subject_type, subject_id)
+);
+`
+
+func setupTagAccessRuleTestDB(t *testing.T) *tagAccessRuleRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(tagAccessRuleTestDDL).Error)
+	return &tagAccessRuleRepository{db: db}
+}
+
+func newTagAccessRule(kbID, subjectType, subjectID string, tagIDs ...string) *types.TagAccessRule {
+	now := time.Now()
+	return &types.TagAccessRule{
+		ID:              uuid.New().String(),
+		TenantID:        1,
+		KnowledgeBaseID: kbID,
+		SubjectType:     subjectType,
+		SubjectID:       subjectID,
+		TagIDs:          tagIDs,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+func TestTagAccessRule_SaveReplacesSubjectRule(t *testing.T) {
+	repo := setupTagAccessRuleTestDB(t)
+	ctx := context.Background()
+	kbID := uuid.New().String()
+
+	first := newTagAccessRule(kbID, types.TagAccessSubjectUser, "u1", "tag-a")
+	require.NoError(t, repo.Save(ctx, first))
+	require.NoError(t, repo.Save(ctx, newTagAccessRule(kbID, types.TagAccessSubjectUser, "u1", "tag-b", "tag-c")))
+
+	rules, err := repo.ListByKB(ctx, 1, kbID)
+	require.NoError(t, err)
+	require.Len(t, rules, 1)
+	assert.Equal(t, first.ID, rules[0].ID)
+	assert.Equal(t, types.StringArray{"tag-b", "tag-c"}, rules[0].TagIDs)
+}
+
+func TestTagAccessRule_ListForSubjects(t *testing.T) {
+	repo := setupTagAccessRuleTestDB(t)
+	ctx := context.Background()
+	kbA := uuid.New().String()
+	kbB := uuid.New().String()
+
+	require.NoError(t, repo.Save(ctx, newTagAccessRule(kbA, types.TagAccessSubjectUser, "u1", "tag-a")))
+	require.NoError(t, repo.Save(ctx, newTagAccessRule(kbA, types.TagAccessSubjectUser, "u2", "tag-b")))
+	require.NoError(t, repo.Save(ctx, newTagAccessRule(kbB, types.TagAccessSubjectRole, "viewer", "tag-c")))
+	require.NoError(t, repo.Save(ctx, newTagAccessRule(kbB, types.TagAccessSubjectAPIKey, "", "tag-d")))
+
+	rules, err := repo.ListForSubjects(ctx, 1, []string{kbA, kbB}, []types.TagAccessSubject{
+		{Type: types.TagAccessSubjectUser, ID: "u1"},
+		{Type: types.TagAccessSubjectRole, ID: "viewer"},
+	})
+	require.NoError(t, err)
+	got := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		got = append(got, rule.TagIDs...)
+	}
+	assert.ElementsMatch(t, []string{"tag-a", "tag-c"}, got)
+
+	require.NoError(t, repo.Delete(ctx, 1, rules[0].KnowledgeBaseID, rules[0].ID))
+	assert.ErrorIs(t, repo.Delete(ctx, 1, rules[0].KnowledgeBaseID, rules[0].ID), gorm.ErrRecordNotFound)
+}
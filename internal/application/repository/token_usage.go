@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// tokenUsageRepository implements the TokenUsageRepository interface
+type tokenUsageRepository struct {
+	db *gorm.DB
+}
+
+// NewTokenUsageRepository creates a new token usage repository
+func NewTokenUsageRepository(db *gorm.DB) interfaces.TokenUsageRepository {
+	return &tokenUsageRepository{db: db}
+}
+
+// tokenUsageGroupColumns maps a report grouping to the column it groups by
+var tokenUsageGroupColumns = map[types.TokenUsageGroupBy]string{
+	types.TokenUsageGroupByDay:     "day",
+	types.TokenUsageGroupByModel:   "model_id",
+	types.TokenUsageGroupBySession: "session_id",
+}
+
+// AddUsage upserts the aggregate row, adding the counts of usage to it
+func (r *tokenUsageRepository) AddUsage(ctx context.Context, usage *types.TokenUsageDaily) error {
+	usage.UpdatedAt = time.Now()
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{
+			{Name: "tenant_id"},
+			{Name: "day"},
+			{Name: "model_id"},
+			{Name: "session_id"},
+		},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "model_name"}, Value: gorm.Expr("excluded.model_name")},
+			{Column: clause.Column{Name: "requests"}, Value: gorm.Expr("token_usage_daily.requests + excluded.requests")},
+			{Column: clause.Column{Name: "estimated_requests"}, Value: gorm.Expr(
+				"token_usage_daily.estimated_requests + excluded.estimated_requests")},
+			{Column: clause.Column{Name: "prompt_tokens"}, Value: gorm.Expr(
+				"token_usage_daily.prompt_tokens + excluded.prompt_tokens")},
+			{Column: clause.Column{Name: "completion_tokens"}, Value: gorm.Expr(
+				"token_usage_daily.completion_tokens + excluded.completion_tokens")},
+			{Column: clause.Column{Name: "embedding_tokens"}, Value: gorm.Expr(
+				"token_usage_daily.embedding_tokens + excluded.embedding_tokens")},
+			{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("excluded.updated_at")},
+		},
+	}).Create(usage).Error
+}
+
+// Summarize sums the usage matching query per group
+func (r *tokenUsageRepository) Summarize(
+	ctx context.Context, query *types.TokenUsageQuery,
+) ([]types.TokenUsageSummary, error) {
+	column, ok := tokenUsageGroupColumns[query.GroupBy]
+	if !ok {
+		column = tokenUsageGroupColumns[types.TokenUsageGroupByDay]
+	}
+	db := r.db.WithContext(ctx).Model(&types.TokenUsageDaily{}).
+		Select(column+" AS group_key, MAX(model_name) AS model_name, "+
+			"SUM(requests) AS requests, SUM(estimated_requests) AS estimated_requests, "+
+			"SUM(prompt_tokens) AS prompt_tokens, SUM(completion_tokens) AS completion_tokens, "+
+			"SUM(embedding_tokens) AS embedding_tokens, "+
+			"SUM(prompt_tokens + completion_tokens + embedding_tokens) AS total_tokens").
+		Where("tenant_id = ? AND day >= ? AND day <= ?", query.TenantID, query.StartDay, query.EndDay)
+	if query.ModelID != "" {
+		db = db.Where("model_id = ?", query.ModelID)
+	}
+	if query.SessionID != "" {
+		db = db.Where("session_id = ?", query.SessionID)
+	}
+	if query.GroupBy == types.TokenUsageGroupByDay {
+		db = db.Group(column).Order(column + " ASC")
+	} else {
+		db = db.Group(column).Order("total_tokens DESC")
+	}
+
+	var rows []struct {
+		GroupKey string
+		types.TokenUsageSummary
+	}
+	if err := db.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	summaries := make([]types.TokenUsageSummary, 0, len(rows))
+	for _, row := range rows {
+		summary := row.TokenUsageSummary
+		summary.Key = row.GroupKey
+		// The model name only identifies a group of one model.
+		if query.GroupBy != types.TokenUsageGroupByModel {
+			summary.ModelName = ""
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+// TotalTokens sums all tokens of a tenant over a day range
+func (r *tokenUsageRepository) TotalTokens(ctx context.Context, tenantID uint64, startDay, endDay string) (int64, error) {
+	var total int64
+	err := r.db.WithContext(ctx).Model(&types.TokenUsageDaily{}).
+		Where("tenant_id = ? AND day >= ? AND day <= ?", tenantID, startDay, endDay).
+		Select("COALESCE(SUM(prompt_tokens + completion_tokens + embedding_tokens), 0)").
+		Row().Scan(&total)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestTokenUsageRepository_AddUsageAndSummarize(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&types.TokenUsageDaily{}))
+	repo := NewTokenUsageRepository(db)
+	ctx := context.Background()
+
+	rows := []types.TokenUsageDaily{
+		{TenantID: 1, Day: "2026-10-01", ModelID: "chat", SessionID: "s1", ModelName: "qwen", Requests: 1,
+			PromptTokens: 100, CompletionTokens: 20},
+		{TenantID: 1, Day: "2026-10-01", ModelID: "chat", SessionID: "s1", ModelName: "qwen-plus", Requests: 1,
+			EstimatedRequests: 1, PromptTokens: 50, CompletionTokens: 10},
+		{TenantID: 1, Day: "2026-10-02", ModelID: "embed", ModelName: "bge", Requests: 3, EstimatedRequests: 3,
+			EmbeddingTokens: 300},
+		{TenantID: 2, Day: "2026-10-01", ModelID: "chat", SessionID: "s9", ModelName: "qwen", Requests: 1,
+			PromptTokens: 1000},
+	}
+	for i := range rows {
+		require.NoError(t, repo.AddUsage(ctx, &rows[i]))
+	}
+
+	var stored []types.TokenUsageDaily
+	require.NoError(t, db.Where("tenant_id = ?", 1).Order("day").Find(&stored).Error)
+	require.Len(t, stored, 2, "calls of the same day, model and session share a row")
+	assert.Equal(t, int64(2), stored[0].Requests)
+	assert.Equal(t, int64(150), stored[0].PromptTokens)
+	assert.Equal(t, "qwen-plus", stored[0].ModelName)
+
+	byDay, err := repo.Summarize(ctx, &types.TokenUsageQuery{
+		TenantID: 1, StartDay: "2026-10-01", EndDay: "2026-10-31", GroupBy: types.TokenUsageGroupByDay,
+	})
+	require.NoError(t, err)
+	require.Len(t, byDay, 2)
+	assert.Equal(t, "2026-10-01", byDay[0].Key)
+	assert.Equal(t, int64(180), byDay[0].TotalTokens)
+	assert.Empty(t, byDay[0].ModelName)
+	assert.Equal(t, int64(300), byDay[1].EmbeddingTokens)
+
+	byModel, err := repo.Summarize(ctx, &types.TokenUsageQuery{
+		TenantID: 1, StartDay: "2026-10-01", EndDay: "2026-10-31", GroupBy: types.TokenUsageGroupByModel,
+	})
+	require.NoError(t, err)
+	require.Len(t, byModel, 2)
+	assert.Equal(t, "embed", byModel[0].Key, "groups are ordered by total tokens")
+	assert.Equal(t, "bge", byModel[0].ModelName)
+
+	total, err := repo.TotalTokens(ctx, 1, "2026-10-02", "2026-10-02")
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), total)
+
+	total, err = repo.TotalTokens(ctx, 3, "2026-10-01", "2026-10-31")
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
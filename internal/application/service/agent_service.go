@@ -453,7 +453,7 @@ func (s *agentService) registerTools(
 			// whitelist into the wiki scope so wiki_search / wiki_read_page
 			// only surface pages whose SourceRefs intersect the pinned docs.
 			scope := tools.WikiScope{KnowledgeBaseID: kb.ID}
-			if target.Type == types.SearchTargetTypeKnowledge && len(target.KnowledgeIDs) > 0 {
+			if len(target.KnowledgeIDs) > 0 {
 				scope.KnowledgeIDs = append([]string(nil), target.KnowledgeIDs...)
 			}
 			if len(target.TagIDs) > 0 {
@@ -583,6 +583,8 @@ func (s *agentService) registerTools(
 			toolToRegister = tools.NewSequentialThinkingTool()
 		case tools.ToolTodoWrite:
 			toolToRegister = tools.NewTodoWriteTool()
+		case tools.ToolCalculator:
+			toolToRegister = tools.NewCalculatorTool()
 		case tools.ToolKnowledgeSearch:
 			toolToRegister = tools.NewKnowledgeSearchTool(
 				s.knowledgeBaseService,
@@ -594,7 +596,7 @@ func (s *agentService) registerTools(
 				s.cfg,
 			)
 		case tools.ToolGrepChunks:
-			toolToRegister = tools.NewGrepChunksTool(s.db, config.SearchTargets)
+			toolToRegister = tools.NewGrepChunksTool(s.db, s.knowledgeService, config.SearchTargets)
 			logger.Infof(ctx, "Registered grep_chunks tool with searchTargets: %d targets", len(config.SearchTargets))
 		case tools.ToolListKnowledgeChunks:
 			toolToRegister = tools.NewListKnowledgeChunksTool(s.knowledgeService, s.chunkService, config.SearchTargets)
@@ -603,7 +605,7 @@ func (s *agentService) registerTools(
 		case tools.ToolGetDocumentInfo:
 			toolToRegister = tools.NewGetDocumentInfoTool(s.knowledgeService, s.chunkService, config.SearchTargets)
 		case tools.ToolDatabaseQuery:
-			toolToRegister = tools.NewDatabaseQueryTool(s.db, config.SearchTargets)
+			toolToRegister = tools.NewDatabaseQueryTool(s.db, s.knowledgeService, config.SearchTargets)
 		case tools.ToolWebSearch:
 			toolToRegister = tools.NewWebSearchTool(
 				s.webSearchService,
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// defaultAnalyticsDays is the date range of analytics requested without one
+	defaultAnalyticsDays = 30
+	// maxAnalyticsDays bounds the date range of analytics
+	maxAnalyticsDays = 366
+)
+
+// analyticsService logs every answered chat query with its latency, the
+// knowledge bases it searched and the documents it retrieved and cited,
+// and aggregates the logs for the admin dashboard: query volume, top and
+// zero-result queries, latency percentiles, most cited documents and
+// knowledge base usage over time. Zero-result queries are also recorded as
+// knowledge gaps of the knowledge bases they searched.
+type analyticsService struct {
+	repo       interfaces.AnalyticsRepository
+	kbRepo     interfaces.KnowledgeBaseRepository
+	gapService interfaces.KnowledgeGapService
+	now        func() time.Time
+}
+
+// NewAnalyticsService creates the query analytics service.
+func NewAnalyticsService(
+	repo interfaces.AnalyticsRepository,
+	kbRepo interfaces.KnowledgeBaseRepository,
+	gapService interfaces.KnowledgeGapService,
+) interfaces.AnalyticsService {
+	return &analyticsService{
+		repo:       repo,
+		kbRepo:     kbRepo,
+		gapService: gapService,
+		now:        time.Now,
+	}
+}
+
+// RecordQuery logs a completed answer of the caller's tenant and records
+// the knowledge gaps of a zero-result answer
+func (s *analyticsService) RecordQuery(ctx context.Context, record *types.QueryRecord) {
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok || tenantID == 0 {
+		return
+	}
+	userID, _ := types.UserIDFromContext(ctx)
+	log := types.NewQueryLog(tenantID, userID, record)
+	log.ID = uuid.New().String()
+	if err := s.repo.SaveQueryLog(ctx, log); err != nil {
+		logger.Warnf(ctx, "Failed to log query of message %s: %v", record.MessageID, err)
+	}
+	s.gapService.RecordGaps(ctx, log)
+}
+
+// resolveQuery scopes a query to the caller's tenant and fills in its date
+// range, which defaults to the last 30 days, and its limit.
+func (s *analyticsService) resolveQuery(ctx context.Context, query *types.AnalyticsQuery) (*types.AnalyticsQuery, error) {
+	q := *query
+	q.TenantID = types.MustTenantIDFromContext(ctx)
+	switch {
+	case q.Limit < 0:
+		return nil, werrors.NewBadRequestError("limit 不能为负数")
+	case q.Limit == 0:
+		q.Limit = types.DefaultAnalyticsLimit
+	case q.Limit > types.MaxAnalyticsLimit:
+		return nil, werrors.NewBadRequestError(fmt.Sprintf("limit 不能超过 %d", types.MaxAnalyticsLimit))
+	}
+	var err error
+	q.StartDay, q.EndDay, err = resolveDayRange(s.now(), q.StartDay, q.EndDay, defaultAnalyticsDays, maxAnalyticsDays)
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// GetOverview returns the query, zero-result and latency totals with the
+// per-day counts
+func (s *analyticsService) GetOverview(
+	ctx context.Context, query *types.AnalyticsQuery,
+) (*types.QueryAnalyticsOverview, error) {
+	q, err := s.resolveQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	days, err := s.repo.CountByDay(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	overview := types.NewQueryAnalyticsOverview(q.StartDay, q.EndDay, days)
+	if overview.Latency, err = s.repo.LatencyPercentiles(ctx, q, types.AnalyticsLatencyPercentiles); err != nil {
+		return nil, err
+	}
+	if overview.Latency == nil {
+		overview.Latency = []*types.LatencyPercentile{}
+	}
+	return overview, nil
+}
+
+// TopQueries returns the most asked queries
+func (s *analyticsService) TopQueries(ctx context.Context, query *types.AnalyticsQuery) ([]*types.QueryStat, error) {
+	q, err := s.resolveQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.TopQueries(ctx, q, false)
+}
+
+// ZeroResultQueries returns the queries that most often retrieved nothing
+func (s *analyticsService) ZeroResultQueries(
+	ctx context.Context, query *types.AnalyticsQuery,
+) ([]*types.QueryStat, error) {
+	q, err := s.resolveQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.TopQueries(ctx, q, true)
+}
+
+// TopDocuments returns the documents answers cited most
+func (s *analyticsService) TopDocuments(
+	ctx context.Context, query *types.AnalyticsQuery,
+) ([]*types.DocumentStat, error) {
+	q, err := s.resolveQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.TopDocuments(ctx, q)
+}
+
+// KnowledgeBaseUsage returns the queries per knowledge base and day, with
+// the names of the knowledge bases that still exist
+func (s *analyticsService) KnowledgeBaseUsage(
+	ctx context.Context, query *types.AnalyticsQuery,
+) ([]*types.KnowledgeBaseUsage, error) {
+	q, err := s.resolveQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.repo.CountByKnowledgeBase(ctx, q)
+	if err != nil || len(usage) == 0 {
+		return usage, err
+	}
+	ids := make([]string, 0, len(usage))
+	for _, kb := range usage {
+		ids = append(ids, kb.KnowledgeBaseID)
+	}
+	kbs, err := s.kbRepo.GetKnowledgeBaseByIDs(ctx, ids)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get the names of knowledge bases in analytics: %v", err)
+		return usage, nil
+	}
+	names := make(map[string]string, len(kbs))
+	for _, kb := range kbs {
+		names[kb.ID] = kb.Name
+	}
+	for _, kb := range usage {
+		kb.Name = names[kb.KnowledgeBaseID]
+	}
+	return usage, nil
+}
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// apiKeyLastUsedResolution bounds how often last_used_at is written: a key
+// making many calls a minute updates it at most once a minute.
+const apiKeyLastUsedResolution = time.Minute
+
+// apiKeyDisplayPrefixLen is how much of a secret stays visible as key_prefix.
+const apiKeyDisplayPrefixLen = 10
+
+// errAPIKeyInvalid is returned by Authenticate for unknown, revoked and
+// expired keys alike, so callers cannot probe which keys exist.
+var errAPIKeyInvalid = errors.New("invalid or expired API key")
+
+// apiKeyService implements APIKeyService.
+type apiKeyService struct {
+	repo   interfaces.APIKeyRepository
+	kbRepo interfaces.KnowledgeBaseRepository
+	audit  interfaces.AuditLogService // optional; nil ⇒ no audit, business ops still succeed
+}
+
+// NewAPIKeyService creates a new API key service.
+func NewAPIKeyService(
+	repo interfaces.APIKeyRepository,
+	kbRepo interfaces.KnowledgeBaseRepository,
+	audit interfaces.AuditLogService,
+) interfaces.APIKeyService {
+	return &apiKeyService{repo: repo, kbRepo: kbRepo, audit: audit}
+}
+
+// ListAPIKeys lists the API keys of the caller's tenant.
+func (s *apiKeyService) ListAPIKeys(ctx context.Context) ([]*types.APIKey, error) {
+	return s.repo.ListByTenant(ctx, types.MustTenantIDFromContext(ctx))
+}
+
+// CreateAPIKey creates an API key and returns it with its secret.
+func (s *apiKeyService) CreateAPIKey(
+	ctx context.Context,
+	req *types.APIKeyRequest,
+) (*types.APIKeyWithSecret, error) {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	key := &types.APIKey{
+		ID:        uuid.New().String(),
+		TenantID:  tenantID,
+		CreatedBy: auditActor(ctx),
+	}
+	if err := s.applyRequest(ctx, key, req); err != nil {
+		return nil, err
+	}
+	secret, err := generateAPIKeySecret()
+	if err != nil {
+		return nil, err
+	}
+	setAPIKeySecret(key, secret)
+	if err := s.repo.Create(ctx, key); err != nil {
+		logger.Errorf(ctx, "Failed to create API key: %v", err)
+		return nil, err
+	}
+	logger.Infof(ctx, "Created API key %s (%s) for tenant %d, scopes: %v",
+		key.ID, key.KeyPrefix, tenantID, key.Scopes)
+	s.emitAudit(ctx, key, types.AuditActionAPIKeyCreated, true)
+	return &types.APIKeyWithSecret{APIKey: key, Secret: secret}, nil
+}
+
+// UpdateAPIKey replaces the settings of an API key.
+func (s *apiKeyService) UpdateAPIKey(
+	ctx context.Context,
+	id string,
+	req *types.APIKeyRequest,
+) (*types.APIKey, error) {
+	key, err := s.getAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(ctx, key, req); err != nil {
+		return nil, err
+	}
+	key.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, key); err != nil {
+		logger.Errorf(ctx, "Failed to update API key %s: %v", id, err)
+		return nil, err
+	}
+	s.emitAudit(ctx, key, types.AuditActionAPIKeyUpdated, true)
+	return key, nil
+}
+
+// RotateAPIKey replaces the secret of an API key.
+func (s *apiKeyService) RotateAPIKey(ctx context.Context, id string) (*types.APIKeyWithSecret, error) {
+	key, err := s.getAPIKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := generateAPIKeySecret()
+	if err != nil {
+		return nil, err
+	}
+	setAPIKeySecret(key, secret)
+	key.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, key); err != nil {
+		logger.Errorf(ctx, "Failed to rotate API key %s: %v", id, err)
+		return nil, err
+	}
+	logger.Infof(ctx, "Rotated API key %s, new prefix %s", key.ID, key.KeyPrefix)
+	s.emitAudit(ctx, key, types.AuditActionAPIKeyRotated, false)
+	return &types.APIKeyWithSecret{APIKey: key, Secret: secret}, nil
+}
+
+// RevokeAPIKey revokes an API key.
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id string) error {
+	key, err := s.getAPIKey(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, key.TenantID, key.ID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return werrors.NewNotFoundError("API Key 不存在")
+		}
+		return err
+	}
+	logger.Infof(ctx, "Revoked API key %s (%s)", key.ID, key.KeyPrefix)
+	s.emitAudit(ctx, key, types.AuditActionAPIKeyRevoked, false)
+	return nil
+}
+
+// Authenticate returns the live API key with the secret.
+func (s *apiKeyService) Authenticate(ctx context.Context, secret string) (*types.APIKey, error) {
+	if !strings.HasPrefix(secret, types.APIKeySecretPrefix) {
+		return nil, errAPIKeyInvalid
+	}
+	key, err := s.repo.GetByHash(ctx, types.HashAPIKeySecret(secret))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errAPIKeyInvalid
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if key.IsExpired(now) {
+		return nil, errAPIKeyInvalid
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyLastUsedResolution {
+		if err := s.repo.TouchLastUsed(ctx, key.ID, now); err != nil {
+			logger.Warnf(ctx, "Failed to record last use of API key %s: %v", key.ID, err)
+		} else {
+			key.LastUsedAt = &now
+		}
+	}
+	return key, nil
+}
+
+// getAPIKey loads an API key of the caller's tenant.
+func (s *apiKeyService) getAPIKey(ctx context.Context, id string) (*types.APIKey, error) {
+	key, err := s.repo.GetByID(ctx, types.MustTenantIDFromContext(ctx), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, werrors.NewNotFoundError("API Key 不存在")
+		}
+		return nil, err
+	}
+	return key, nil
+}
+
+// applyRequest validates the request and copies it onto the key.
+func (s *apiKeyService) applyRequest(ctx context.Context, key *types.APIKey, req *types.APIKeyRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return werrors.NewBadRequestError("名称不能为空")
+	}
+	scopes := dedupPreservingOrder(req.Scopes)
+	if len(scopes) == 0 {
+		return werrors.NewBadRequestError("至少需要一个 scope")
+	}
+	for _, scope := range scopes {
+		if !types.IsValidAPIKeyScope(scope) {
+			return werrors.NewBadRequestError("scope 只能是 all、read、chat 或 ingest")
+		}
+	}
+	role := req.Role
+	if role == "" {
+		role = types.DefaultAPIKeyRole(scopes)
+	}
+	if !role.AssignableToAPIKey() {
+		return werrors.NewBadRequestError("API Key 角色只能是 viewer、contributor 或 admin")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return werrors.NewBadRequestError("过期时间必须晚于当前时间")
+	}
+
+	kbIDs := dedupPreservingOrder(req.KnowledgeBaseIDs)
+	if len(kbIDs) > 0 {
+		kbs, err := s.kbRepo.GetKnowledgeBaseByIDs(ctx, kbIDs)
+		if err != nil {
+			return err
+		}
+		found := 0
+		for _, kb := range kbs {
+			if kb != nil && kb.TenantID == key.TenantID {
+				found++
+			}
+		}
+		if found != len(kbIDs) {
+			return werrors.NewBadRequestError("知识库不存在或不属于当前租户")
+		}
+	}
+
+	key.Name = name
+	key.Role = role
+	key.Scopes = scopes
+	key.KnowledgeBaseIDs = kbIDs
+	key.ExpiresAt = req.ExpiresAt
+	return nil
+}
+
+// emitAudit records an API key lifecycle event. withSettings adds the
+// key's role, scopes and knowledge bases to the details.
+func (s *apiKeyService) emitAudit(ctx context.Context, key *types.APIKey, action types.AuditAction, withSettings bool) {
+	if s.audit == nil {
+		return
+	}
+	details := map[string]interface{}{"name": key.Name, "key_prefix": key.KeyPrefix}
+	if withSettings {
+		details["role"] = key.Role
+		details["scopes"] = key.Scopes
+		details["knowledge_base_ids"] = key.KnowledgeBaseIDs
+	}
+	raw, _ := json.Marshal(details)
+	_ = s.audit.Log(ctx, &types.AuditLog{
+		TenantID:    key.TenantID,
+		ActorUserID: auditActor(ctx),
+		ActorRole:   auditActorRole(ctx),
+		Action:      action,
+		TargetType:  "api_key",
+		TargetID:    key.ID,
+		Outcome:     types.AuditOutcomeSuccess,
+		Details:     types.JSON(raw),
+	})
+}
+
+// generateAPIKeySecret returns a new random secret.
+func generateAPIKeySecret() (string, error) {
+	random, err := generateRandomString(32)
+	if err != nil {
+		return "", err
+	}
+	return types.APIKeySecretPrefix + random, nil
+}
+
+// setAPIKeySecret stores the hash and display prefix of secret on the key.
+func setAPIKeySecret(key *types.APIKey, secret string) {
+	key.KeyHash = types.HashAPIKeySecret(secret)
+	key.KeyPrefix = secret[:apiKeyDisplayPrefixLen]
+}
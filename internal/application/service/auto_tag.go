@@ -0,0 +1,458 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// autoTagMaxTaxonomy caps the tags of a knowledge base considered for
+	// classification.
+	autoTagMaxTaxonomy = 1000
+	// autoTagMaxCandidates is the most tags listed in one prompt. Larger
+	// taxonomies are shortlisted by embedding similarity first.
+	autoTagMaxCandidates = 50
+	// autoTagMaxContentRunes bounds the document excerpt sent to the model.
+	autoTagMaxContentRunes = 2000
+	// autoTagRetagDelay debounces re-tagging after taxonomy changes, so
+	// editing several tags in a row re-tags the documents once.
+	autoTagRetagDelay = 2 * time.Minute
+)
+
+const autoTagClassifyPrompt = `You are filing a document under the categories of a knowledge base.
+Pick at most {{max_tags}} categories from the list below that describe the main topics of the document.
+Only use categories from the list, written exactly as given. If no category fits, return an empty array.
+
+Respond with a JSON array of category names only, no commentary:
+["<category>"]
+
+Categories:
+{{tags}}
+
+Document title: {{title}}
+Document summary: {{summary}}
+Document excerpt:
+{{content}}`
+
+// autoTagService files documents under the tags of their knowledge base
+// with the knowledge base's chat model. Tags it attaches are marked as auto
+// tags; a document whose tags were set by hand is never re-tagged.
+type autoTagService struct {
+	kbRepo        interfaces.KnowledgeBaseRepository
+	knowledgeRepo interfaces.KnowledgeRepository
+	chunkRepo     interfaces.ChunkRepository
+	tagRepo       interfaces.KnowledgeTagRepository
+	modelService  interfaces.ModelService
+	task          interfaces.TaskEnqueuer
+}
+
+// NewAutoTagService creates the auto-tagging service.
+func NewAutoTagService(
+	kbRepo interfaces.KnowledgeBaseRepository,
+	knowledgeRepo interfaces.KnowledgeRepository,
+	chunkRepo interfaces.ChunkRepository,
+	tagRepo interfaces.KnowledgeTagRepository,
+	modelService interfaces.ModelService,
+	task interfaces.TaskEnqueuer,
+) interfaces.AutoTagService {
+	return &autoTagService{
+		kbRepo:        kbRepo,
+		knowledgeRepo: knowledgeRepo,
+		chunkRepo:     chunkRepo,
+		tagRepo:       tagRepo,
+		modelService:  modelService,
+		task:          task,
+	}
+}
+
+// autoTagModelID returns the chat model that classifies the documents of kb.
+func autoTagModelID(kb *types.KnowledgeBase) string {
+	if kb.AutoTagConfig != nil && kb.AutoTagConfig.ModelID != "" {
+		return kb.AutoTagConfig.ModelID
+	}
+	return kb.SummaryModelID
+}
+
+// RetagKnowledgeBase enqueues auto-tagging of the given documents of the
+// knowledge base, or of all its documents when knowledgeIDs is empty. It
+// works whether or not auto-tagging is enabled on the knowledge base.
+func (s *autoTagService) RetagKnowledgeBase(ctx context.Context, kbID string, knowledgeIDs []string) error {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil || kb.TenantID != tenantID {
+		return werrors.NewNotFoundError("知识库不存在")
+	}
+	if kb.Type == types.KnowledgeBaseTypeFAQ {
+		return werrors.NewBadRequestError("FAQ 知识库不支持自动打标签")
+	}
+	if autoTagModelID(kb) == "" {
+		return werrors.NewBadRequestError("知识库未配置摘要模型，无法自动打标签")
+	}
+	tags, err := s.listTaxonomy(ctx, tenantID, kbID)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return werrors.NewBadRequestError("知识库还没有标签，无法自动打标签")
+	}
+	return enqueueAutoTag(ctx, s.task, tenantID, kbID, knowledgeIDs)
+}
+
+// ScheduleAutoTag enqueues auto-tagging of one document of kb when kb has
+// auto-tagging enabled. It is called once the document's summary is saved.
+func ScheduleAutoTag(ctx context.Context, client interfaces.TaskEnqueuer, kb *types.KnowledgeBase, knowledgeID string) {
+	if client == nil || kb == nil || kb.AutoTagConfig == nil || !kb.AutoTagConfig.Enabled ||
+		kb.Type == types.KnowledgeBaseTypeFAQ {
+		return
+	}
+	if err := enqueueAutoTag(ctx, client, kb.TenantID, kb.ID, []string{knowledgeID}); err != nil {
+		logger.Warnf(ctx, "Failed to enqueue auto-tagging of knowledge %s: %v", knowledgeID, err)
+	}
+}
+
+// ScheduleAutoRetag enqueues re-tagging of all documents of kb after its
+// tags changed, when kb asks for it. Changes within the same window share
+// one task.
+func ScheduleAutoRetag(ctx context.Context, client interfaces.TaskEnqueuer, kb *types.KnowledgeBase) {
+	if client == nil || kb == nil || kb.AutoTagConfig == nil || !kb.AutoTagConfig.Enabled ||
+		!kb.AutoTagConfig.RetagOnChange || kb.Type == types.KnowledgeBaseTypeFAQ {
+		return
+	}
+	window := time.Now().Truncate(autoTagRetagDelay).Unix()
+	err := enqueueAutoTag(ctx, client, kb.TenantID, kb.ID, nil,
+		asynq.ProcessIn(autoTagRetagDelay),
+		asynq.TaskID(fmt.Sprintf("auto-tag:%s:%d", kb.ID, window)))
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		logger.Warnf(ctx, "Failed to schedule re-tagging of knowledge base %s: %v", kb.ID, err)
+	}
+}
+
+func enqueueAutoTag(
+	ctx context.Context, client interfaces.TaskEnqueuer, tenantID uint64, kbID string, knowledgeIDs []string,
+	opts ...asynq.Option,
+) error {
+	lang, _ := types.LanguageFromContext(ctx)
+	payload := types.AutoTagPayload{
+		TenantID:        tenantID,
+		KnowledgeBaseID: kbID,
+		KnowledgeIDs:    knowledgeIDs,
+		Language:        lang,
+	}
+	langfuse.InjectTracing(ctx, &payload)
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	opts = append([]asynq.Option{asynq.Queue(types.QueueLow), asynq.MaxRetry(2), asynq.Timeout(30 * time.Minute)}, opts...)
+	info, err := client.Enqueue(asynq.NewTask(types.TypeAutoTag, payloadBytes), opts...)
+	if err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Auto-tag task enqueued: %s, knowledge base ID: %s, documents: %d", info.ID, kbID, len(knowledgeIDs))
+	return nil
+}
+
+// ProcessAutoTag classifies the payload's documents against the tags of
+// their knowledge base and replaces their auto tags with the result.
+// Documents with a manually attached tag are skipped.
+func (s *autoTagService) ProcessAutoTag(ctx context.Context, t *asynq.Task) error {
+	var payload types.AutoTagPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "Failed to unmarshal auto-tag payload: %v", err)
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	if payload.Language != "" {
+		ctx = context.WithValue(ctx, types.LanguageContextKey, payload.Language)
+	}
+	tenantID, kbID := payload.TenantID, payload.KnowledgeBaseID
+
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	if kb == nil || kb.TenantID != tenantID {
+		logger.Warnf(ctx, "Knowledge base %s not found, skip auto-tagging", kbID)
+		return nil
+	}
+	modelID := autoTagModelID(kb)
+	if modelID == "" {
+		logger.Warnf(ctx, "Knowledge base %s has no summary model, skip auto-tagging", kbID)
+		return nil
+	}
+	chatModel, err := s.modelService.GetChatModel(ctx, modelID)
+	if err != nil {
+		return fmt.Errorf("get auto-tag model: %w", err)
+	}
+	tags, err := s.listTaxonomy(ctx, tenantID, kbID)
+	if err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		logger.Infof(ctx, "Knowledge base %s has no tags, skip auto-tagging", kbID)
+		return nil
+	}
+	knowledges, err := s.listTargets(ctx, tenantID, kbID, payload.KnowledgeIDs)
+	if err != nil {
+		return err
+	}
+	if len(knowledges) == 0 {
+		return nil
+	}
+	ids := make([]string, len(knowledges))
+	for i, k := range knowledges {
+		ids[i] = k.ID
+	}
+	current, err := s.knowledgeRepo.GetKnowledgeTags(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	// Large taxonomies are shortlisted per document by embedding
+	// similarity; without an embedding model the full list is sent.
+	var embedder embedding.Embedder
+	var tagVectors [][]float32
+	if len(tags) > autoTagMaxCandidates && kb.EmbeddingModelID != "" {
+		embedder, tagVectors = s.embedTaxonomy(ctx, kb.EmbeddingModelID, tags)
+	}
+
+	maxTags := kb.AutoTagConfig.GetMaxTags()
+	var tagged, skipped, failed int
+	for _, k := range knowledges {
+		if hasManualTag(current[k.ID]) {
+			skipped++
+			continue
+		}
+		content, err := s.documentExcerpt(ctx, tenantID, k.ID)
+		if err != nil {
+			logger.Warnf(ctx, "Auto-tag: failed to read knowledge %s: %v", k.ID, err)
+			failed++
+			continue
+		}
+		candidates := tags
+		if tagVectors != nil {
+			candidates = shortlistAutoTags(ctx, embedder, tags, tagVectors, k, content)
+		}
+		tagIDs, err := classifyAutoTags(ctx, chatModel, k, content, candidates, maxTags)
+		if err != nil {
+			logger.Warnf(ctx, "Auto-tag: failed to classify knowledge %s: %v", k.ID, err)
+			failed++
+			continue
+		}
+		if err := s.knowledgeRepo.SetAutoKnowledgeTags(ctx, k.ID, tagIDs); err != nil {
+			return fmt.Errorf("set auto tags of knowledge %s: %w", k.ID, err)
+		}
+		tagged++
+	}
+	logger.Infof(ctx, "Auto-tagging of knowledge base %s done: %d tagged, %d manually tagged skipped, %d failed",
+		kbID, tagged, skipped, failed)
+	return nil
+}
+
+// listTaxonomy returns the tags documents can be filed under.
+func (s *autoTagService) listTaxonomy(ctx context.Context, tenantID uint64, kbID string) ([]*types.KnowledgeTag, error) {
+	tags, _, err := s.tagRepo.ListByKB(ctx, tenantID, kbID, &types.Pagination{Page: 1, PageSize: autoTagMaxTaxonomy}, "")
+	if err != nil {
+		return nil, err
+	}
+	taxonomy := make([]*types.KnowledgeTag, 0, len(tags))
+	for _, tag := range tags {
+		if tag.Name == types.UntaggedTagName {
+			continue
+		}
+		taxonomy = append(taxonomy, tag)
+	}
+	return taxonomy, nil
+}
+
+// listTargets returns the documents to tag. Named documents are tagged as
+// long as they are not being cancelled or deleted; a whole-knowledge-base
+// run only covers parsed documents, the rest are tagged once their summary
+// is saved.
+func (s *autoTagService) listTargets(
+	ctx context.Context, tenantID uint64, kbID string, knowledgeIDs []string,
+) ([]*types.Knowledge, error) {
+	var knowledges []*types.Knowledge
+	var err error
+	if len(knowledgeIDs) > 0 {
+		knowledges, err = s.knowledgeRepo.GetKnowledgeBatch(ctx, tenantID, knowledgeIDs)
+	} else {
+		knowledges, err = s.knowledgeRepo.ListKnowledgeByKnowledgeBaseID(ctx, tenantID, kbID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]*types.Knowledge, 0, len(knowledges))
+	for _, k := range knowledges {
+		if k.KnowledgeBaseID != kbID {
+			continue
+		}
+		switch k.Type {
+		case types.KnowledgeTypeFAQ, types.KnowledgeTypeGlossary, types.KnowledgeTypeGraphCommunity:
+			continue
+		}
+		switch k.ParseStatus {
+		case types.ParseStatusCancelled, types.ParseStatusDeleting:
+			continue
+		case types.ParseStatusCompleted:
+		default:
+			if len(knowledgeIDs) == 0 {
+				continue
+			}
+		}
+		targets = append(targets, k)
+	}
+	return targets, nil
+}
+
+func hasManualTag(tags []*types.KnowledgeTag) bool {
+	for _, tag := range tags {
+		if tag.Source != types.KnowledgeTagSourceAuto {
+			return true
+		}
+	}
+	return false
+}
+
+// documentExcerpt returns the start of the document's text, up to
+// autoTagMaxContentRunes.
+func (s *autoTagService) documentExcerpt(ctx context.Context, tenantID uint64, knowledgeID string) (string, error) {
+	chunks, err := s.chunkRepo.ListChunksByKnowledgeID(ctx, tenantID, knowledgeID)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	remaining := autoTagMaxContentRunes
+	for _, c := range chunks {
+		if !c.IsEnabled || remaining <= 0 {
+			continue
+		}
+		runes := []rune(strings.TrimSpace(c.Content))
+		if len(runes) > remaining {
+			runes = runes[:remaining]
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(string(runes))
+		remaining -= len(runes)
+	}
+	return b.String(), nil
+}
+
+// embedTaxonomy embeds the tag names. It returns nil vectors when the
+// embedding model is unavailable, which sends the full taxonomy instead.
+func (s *autoTagService) embedTaxonomy(
+	ctx context.Context, embeddingModelID string, tags []*types.KnowledgeTag,
+) (embedding.Embedder, [][]float32) {
+	embedder, err := s.modelService.GetEmbeddingModel(ctx, embeddingModelID)
+	if err != nil {
+		logger.Warnf(ctx, "Auto-tag: embedding model unavailable, listing all tags: %v", err)
+		return nil, nil
+	}
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	vectors, err := embedder.BatchEmbed(ctx, names)
+	if err != nil || len(vectors) != len(tags) {
+		logger.Warnf(ctx, "Auto-tag: failed to embed tags, listing all tags: %v", err)
+		return nil, nil
+	}
+	return embedder, vectors
+}
+
+// shortlistAutoTags returns the autoTagMaxCandidates tags closest to the
+// document, or all tags when the document cannot be embedded.
+func shortlistAutoTags(
+	ctx context.Context, embedder embedding.Embedder, tags []*types.KnowledgeTag, tagVectors [][]float32,
+	k *types.Knowledge, content string,
+) []*types.KnowledgeTag {
+	text := strings.TrimSpace(k.Title + "\n" + k.Description)
+	if strings.TrimSpace(k.Description) == "" {
+		text = strings.TrimSpace(k.Title + "\n" + content)
+	}
+	vector, err := embedder.Embed(ctx, text)
+	if err != nil {
+		logger.Warnf(ctx, "Auto-tag: failed to embed knowledge %s, listing all tags: %v", k.ID, err)
+		return tags
+	}
+	order := make([]int, len(tags))
+	scores := make([]float64, len(tags))
+	for i := range tags {
+		order[i] = i
+		scores[i] = cosineSimilarity(vector, tagVectors[i])
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+	shortlist := make([]*types.KnowledgeTag, 0, autoTagMaxCandidates)
+	for _, i := range order[:min(autoTagMaxCandidates, len(order))] {
+		shortlist = append(shortlist, tags[i])
+	}
+	return shortlist
+}
+
+// classifyAutoTags asks the model which candidates describe the document
+// and returns their IDs, at most maxTags. Names the model made up are
+// dropped.
+func classifyAutoTags(
+	ctx context.Context, chatModel chat.Chat, k *types.Knowledge, content string,
+	candidates []*types.KnowledgeTag, maxTags int,
+) ([]string, error) {
+	byName := make(map[string]string, len(candidates))
+	var list strings.Builder
+	for _, tag := range candidates {
+		byName[strings.ToLower(strings.TrimSpace(tag.Name))] = tag.ID
+		list.WriteString("- " + tag.Name + "\n")
+	}
+	prompt := types.RenderPromptPlaceholders(autoTagClassifyPrompt, types.PlaceholderValues{
+		"max_tags": strconv.Itoa(maxTags),
+		"tags":     list.String(),
+		"title":    k.Title,
+		"summary":  k.Description,
+		"content":  content,
+	})
+
+	thinking := false
+	resp, err := chatModel.Chat(ctx, []chat.Message{{Role: "user", Content: prompt}}, &chat.ChatOptions{
+		Temperature: 0.1,
+		MaxTokens:   512,
+		Thinking:    &thinking,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	if err := json.Unmarshal([]byte(cleanLLMJSON(resp.Content)), &names); err != nil {
+		return nil, fmt.Errorf("parse categories: %w", err)
+	}
+	tagIDs := make([]string, 0, maxTags)
+	seen := make(map[string]bool)
+	for _, name := range names {
+		id, ok := byName[strings.ToLower(strings.TrimSpace(name))]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		tagIDs = append(tagIDs, id)
+		if len(tagIDs) == maxTags {
+			break
+		}
+	}
+	return tagIDs, nil
+}
@@ -3,6 +3,7 @@ package chatpipeline
 import (
 	"context"
 
+	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 )
@@ -56,7 +57,13 @@ func (p *PluginChatCompletion) OnEvent(
 	pipelineInfo(ctx, "Completion", "model_call", map[string]interface{}{
 		"chat_model": chatManage.ChatModelID,
 	})
-	chatResponse, err := chatModel.Chat(ctx, chatMessages, opt)
+	var chatResponse *types.ChatResponse
+	chatModel, chatMessages, err = callWithFallbacks(ctx, p.modelService, chatManage, chatModel, chatMessages,
+		func(model chat.Chat, messages []chat.Message) error {
+			var callErr error
+			chatResponse, callErr = model.Chat(ctx, messages, opt)
+			return callErr
+		})
 	if err != nil {
 		pipelineError(ctx, "Completion", "model_call", map[string]interface{}{
 			"chat_model": chatManage.ChatModelID,
@@ -72,5 +79,15 @@ func (p *PluginChatCompletion) OnEvent(
 		"prompt_tokens":     chatResponse.Usage.PromptTokens,
 	})
 	chatManage.ChatResponse = chatResponse
+	if answerVerificationEnabled(chatManage) {
+		chatResponse.Content, chatManage.AnswerVerification = verifyAnswer(
+			ctx, chatModel, chatManage, chatMessages, opt, chatResponse.Content)
+	}
+	if citationsEnabled(chatManage) {
+		chatManage.Citations = extractCitations(chatResponse.Content, chatManage.CitationSources)
+	}
+	if followUpsEnabled(chatManage) {
+		chatManage.FollowUpQuestions = suggestFollowUps(ctx, p.modelService, chatManage, chatResponse.Content)
+	}
 	return next()
 }
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/google/uuid"
@@ -79,7 +81,13 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 	pipelineInfo(ctx, "Stream", "model_call", map[string]interface{}{
 		"chat_model": chatManage.ChatModelID,
 	})
-	responseChan, err := chatModel.ChatStream(ctx, chatMessages, opt)
+	var responseChan <-chan types.StreamResponse
+	chatModel, chatMessages, err = callWithFallbacks(ctx, p.modelService, chatManage, chatModel, chatMessages,
+		func(model chat.Chat, messages []chat.Message) error {
+			var streamErr error
+			responseChan, streamErr = model.ChatStream(ctx, messages, opt)
+			return streamErr
+		})
 	if err != nil {
 		pipelineError(ctx, "Stream", "model_call", map[string]interface{}{
 			"chat_model": chatManage.ChatModelID,
@@ -104,10 +112,12 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 	// and plain answer text to EventAgentFinalAnswer, matching the Agent pipeline.
 	// The goroutine monitors ctx.Done() to avoid leaking when the context is cancelled
 	// and the upstream channel is not closed promptly.
+	metadata := chatManage.AnswerMetadata
 	go func() {
 		thinkingID := fmt.Sprintf("%s-thinking", uuid.New().String()[:8])
 		answerID := fmt.Sprintf("%s-answer", uuid.New().String()[:8])
 		thinkingOpen := false
+		var answer strings.Builder
 
 		closeThinking := func() {
 			if !thinkingOpen {
@@ -181,13 +191,23 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 
 				if response.ResponseType == types.ResponseTypeAnswer {
 					closeThinking()
+					answer.WriteString(response.Content)
+					if response.Done && (answerVerificationEnabled(chatManage) || followUpsEnabled(chatManage)) {
+						p.finishAnswer(ctx, chatManage, chatModel, chatMessages, opt,
+							answerID, answer.String(), response.Content)
+						continue
+					}
+					if response.Done {
+						emitCitations(ctx, chatManage, answer.String())
+					}
 					eventBus.Emit(ctx, types.Event{
 						ID:        answerID,
 						Type:      types.EventType(event.EventAgentFinalAnswer),
 						SessionID: chatManage.SessionID,
 						Data: event.AgentFinalAnswerData{
-							Content: response.Content,
-							Done:    response.Done,
+							Content:  response.Content,
+							Done:     response.Done,
+							Metadata: metadata,
 						},
 					})
 				}
@@ -197,3 +217,52 @@ func (p *PluginChatCompletionStream) OnEvent(ctx context.Context,
 
 	return next()
 }
+
+// finishAnswer runs the post-answer steps, answer verification and
+// follow-up suggestions, before completing the streamed answer. Their events
+// are emitted ahead of the final chunk, which carries the disclaimer if one
+// was added. A regenerated answer is streamed as a new answer event after
+// the verification, whose regenerated flag tells clients to drop the answer
+// streamed so far.
+func (p *PluginChatCompletionStream) finishAnswer(ctx context.Context, chatManage *types.ChatManage,
+	chatModel chat.Chat, chatMessages []chat.Message, opt *chat.ChatOptions,
+	answerID, answer, lastChunk string,
+) {
+	eventBus := chatManage.EventBus
+	emitAnswer := func(id, content string, done bool) {
+		eventBus.Emit(ctx, types.Event{
+			ID:        id,
+			Type:      types.EventType(event.EventAgentFinalAnswer),
+			SessionID: chatManage.SessionID,
+			Data: event.AgentFinalAnswerData{
+				Content:  content,
+				Done:     done,
+				Metadata: chatManage.AnswerMetadata,
+			},
+		})
+	}
+
+	// Flush the last chunk so the streamed answer is complete while the
+	// post-answer steps run.
+	if lastChunk != "" {
+		emitAnswer(answerID, lastChunk, false)
+	}
+	delivered := answer
+	var verification *types.AnswerVerification
+	if answerVerificationEnabled(chatManage) {
+		delivered, verification = verifyAnswer(ctx, chatModel, chatManage, chatMessages, opt, answer)
+	}
+	finalID, tail := answerID, strings.TrimPrefix(delivered, answer)
+	if verification != nil && verification.Regenerated {
+		finalID, tail = fmt.Sprintf("%s-answer", uuid.New().String()[:8]), delivered
+	}
+	emitVerification(ctx, chatManage, verification)
+	emitCitations(ctx, chatManage, delivered)
+	if tail != "" {
+		emitAnswer(finalID, tail, false)
+	}
+	if followUpsEnabled(chatManage) {
+		emitFollowUps(ctx, chatManage, suggestFollowUps(ctx, p.modelService, chatManage, delivered))
+	}
+	emitAnswer(finalID, "", true)
+}
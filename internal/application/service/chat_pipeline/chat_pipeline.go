@@ -2,8 +2,12 @@ package chatpipeline
 
 import (
 	"context"
+	"errors"
 
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/tracing/telemetry"
 	"github.com/Tencent/WeKnora/internal/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // Plugin defines the interface for chat pipeline plugins
@@ -26,14 +30,24 @@ type EventManager struct {
 	listeners map[types.EventType][]Plugin
 	// Map of event types to handler functions
 	handlers map[types.EventType]func(context.Context, types.EventType, *types.ChatManage) *PluginError
+	// Map of event types to hooks, ordered by priority
+	hooks map[types.EventType][]registeredHook
 }
 
-// NewEventManager creates and initializes a new EventManager
+// NewEventManager creates and initializes a new EventManager with the hooks
+// registered through RegisterHook
 func NewEventManager() *EventManager {
-	return &EventManager{
+	e := &EventManager{
 		listeners: make(map[types.EventType][]Plugin),
 		handlers:  make(map[types.EventType]func(context.Context, types.EventType, *types.ChatManage) *PluginError),
+		hooks:     make(map[types.EventType][]registeredHook),
 	}
+	globalHooksMu.Lock()
+	defer globalHooksMu.Unlock()
+	for _, h := range globalHooks {
+		e.addHook(h)
+	}
+	return e
 }
 
 // Register adds a plugin to the EventManager and sets up its event handlers
@@ -67,16 +81,45 @@ func (e *EventManager) buildHandler(plugins []Plugin) func(
 	return next
 }
 
-// Trigger invokes the handler for the specified event type
+// Trigger invokes the handler for the specified event type, between the
+// event's before and after hooks. Each event runs in its own OpenTelemetry
+// span, and errors are counted per stage in the Prometheus metrics.
 func (e *EventManager) Trigger(ctx context.Context,
 	eventType types.EventType, chatManage *types.ChatManage,
 ) *PluginError {
-	if handler, ok := e.handlers[eventType]; ok {
-		return handler(ctx, eventType, chatManage)
+	ctx, span := telemetry.Start(ctx, "pipeline."+string(eventType),
+		telemetry.AttrSessionID.String(chatManage.SessionID),
+		attribute.String("pipeline.message_id", chatManage.MessageID),
+	)
+	err := e.trigger(ctx, eventType, chatManage)
+	if err != nil {
+		metrics.RecordPipelineError(ctx, string(eventType), err.ErrorType)
+		span.SetAttributes(attribute.String("pipeline.error_type", err.ErrorType))
+		cause := err.Err
+		if cause == nil {
+			cause = errors.New(err.Description)
+		}
+		telemetry.End(span, cause)
+		return err
 	}
+	span.End()
 	return nil
 }
 
+func (e *EventManager) trigger(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage,
+) *PluginError {
+	if err := e.runHooks(ctx, HookPhaseBefore, eventType, chatManage); err != nil {
+		return err
+	}
+	if handler, ok := e.handlers[eventType]; ok {
+		if err := handler(ctx, eventType, chatManage); err != nil {
+			return err
+		}
+	}
+	return e.runHooks(ctx, HookPhaseAfter, eventType, chatManage)
+}
+
 // PluginError represents an error in plugin execution
 type PluginError struct {
 	Err         error  // Original error
@@ -122,6 +165,10 @@ var (
 		Description: "Failed to get conversation history",
 		ErrorType:   "get_history_failed",
 	}
+	ErrGuardrailBlocked = &PluginError{
+		Description: "Blocked by guardrails",
+		ErrorType:   "guardrail_blocked",
+	}
 )
 
 // clone creates a copy of the PluginError
@@ -0,0 +1,96 @@
+package chatpipeline
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/google/uuid"
+)
+
+// citationInstruction is appended to the system prompt when the retrieved
+// chunks are numbered, so the answer cites them in a form that can be
+// mapped back to the chunks.
+const citationInstruction = `## Citations
+Each retrieved passage is wrapped in <context id="n">. When a statement relies on a passage, cite it right after the statement as [n] using the passage's id, e.g. "... [1][3]". Cite only passages you actually used, never invent ids, and do not list the sources again at the end of the answer.`
+
+// citationMarkerRe matches [1], [1][2] (one marker at a time) and [1, 2].
+// The optional trailing "(" catches markdown links such as [1](url), which
+// are not citations.
+var citationMarkerRe = regexp.MustCompile(`\[(\d{1,3}(?:\s*[,，、]\s*\d{1,3})*)\](\()?`)
+
+// citationsEnabled reports whether the answer is asked to cite the
+// retrieved chunks.
+func citationsEnabled(chatManage *types.ChatManage) bool {
+	return !chatManage.DisableCitations && len(chatManage.CitationSources) > 0
+}
+
+// extractCitations maps the [n] markers of answer to the n-th source. Each
+// source is listed once, in order of its first citation; markers without a
+// matching source are ignored.
+func extractCitations(answer string, sources []*types.SearchResult) types.Citations {
+	var citations types.Citations
+	seen := make(map[int]bool)
+	for _, m := range citationMarkerRe.FindAllStringSubmatch(answer, -1) {
+		if m[2] != "" {
+			continue
+		}
+		for _, part := range strings.FieldsFunc(m[1], func(r rune) bool {
+			return r == ',' || r == '，' || r == '、' || r == ' '
+		}) {
+			n, err := strconv.Atoi(part)
+			if err != nil || n < 1 || n > len(sources) || seen[n] {
+				continue
+			}
+			seen[n] = true
+			source := sources[n-1]
+			if source == nil {
+				continue
+			}
+			title := source.KnowledgeTitle
+			if title == "" {
+				title = source.KnowledgeFilename
+			}
+			citations = append(citations, types.Citation{
+				Index:           n,
+				ChunkID:         source.ID,
+				KnowledgeID:     source.KnowledgeID,
+				KnowledgeBaseID: source.KnowledgeBaseID,
+				KnowledgeTitle:  title,
+			})
+		}
+	}
+	return citations
+}
+
+// emitCitations streams the sources cited by answer as a citations event.
+// It must run before the final answer chunk, which completes the message.
+func emitCitations(ctx context.Context, chatManage *types.ChatManage, answer string) {
+	if !citationsEnabled(chatManage) || chatManage.EventBus == nil {
+		return
+	}
+	citations := extractCitations(answer, chatManage.CitationSources)
+	pipelineInfo(ctx, "Citation", "output", map[string]interface{}{
+		"session_id":   chatManage.SessionID,
+		"source_cnt":   len(chatManage.CitationSources),
+		"citation_cnt": len(citations),
+	})
+	if len(citations) == 0 {
+		return
+	}
+	if err := chatManage.EventBus.Emit(ctx, types.Event{
+		ID:        fmt.Sprintf("%s-citations", uuid.New().String()[:8]),
+		Type:      types.EventType(event.EventAgentCitations),
+		SessionID: chatManage.SessionID,
+		Data:      event.AgentCitationsData{Citations: citations},
+	}); err != nil {
+		pipelineWarn(ctx, "Citation", "emit_error", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+	}
+}
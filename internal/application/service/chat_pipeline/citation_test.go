@@ -0,0 +1,41 @@
+package chatpipeline
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractCitations(t *testing.T) {
+	sources := []*types.SearchResult{
+		{ID: "c1", KnowledgeID: "k1", KnowledgeBaseID: "kb", KnowledgeTitle: "Handbook"},
+		{ID: "c2", KnowledgeID: "k2", KnowledgeFilename: "faq.md"},
+		{ID: "c3", KnowledgeID: "k1"},
+	}
+
+	citations := extractCitations("Leave is 10 days [2][1]. It accrues monthly [1, 3]. See [9] and [docs](http://x) or [1](http://x).", sources)
+
+	assert.Equal(t, types.Citations{
+		{Index: 2, ChunkID: "c2", KnowledgeID: "k2", KnowledgeTitle: "faq.md"},
+		{Index: 1, ChunkID: "c1", KnowledgeID: "k1", KnowledgeBaseID: "kb", KnowledgeTitle: "Handbook"},
+		{Index: 3, ChunkID: "c3", KnowledgeID: "k1"},
+	}, citations)
+}
+
+func TestExtractCitations_none(t *testing.T) {
+	sources := []*types.SearchResult{{ID: "c1"}}
+	assert.Empty(t, extractCitations("No sources here, array[0] aside.", sources))
+	assert.Empty(t, extractCitations("Out of range [2].", sources))
+}
+
+func TestCitationsEnabled(t *testing.T) {
+	cm := &types.ChatManage{}
+	assert.False(t, citationsEnabled(cm))
+
+	cm.CitationSources = []*types.SearchResult{{ID: "c1"}}
+	assert.True(t, citationsEnabled(cm))
+
+	cm.DisableCitations = true
+	assert.False(t, citationsEnabled(cm))
+}
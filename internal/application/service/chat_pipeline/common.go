@@ -32,6 +32,21 @@ func pipelineError(ctx context.Context, stage, action string, fields map[string]
 	common.PipelineError(ctx, stage, action, fields)
 }
 
+// traceExclusion records a dropped candidate in the request's retrieval
+// trace. It is a no-op unless the caller asked for a debug trace.
+func traceExclusion(ctx context.Context, stage string, reason types.ChunkExclusionReason,
+	r *types.SearchResult, detail string,
+) {
+	types.RetrievalTraceFromContext(ctx).Exclude(types.ChunkExclusion{
+		ChunkID:     r.ID,
+		KnowledgeID: r.KnowledgeID,
+		Stage:       stage,
+		Reason:      reason,
+		Score:       r.Score,
+		Detail:      detail,
+	})
+}
+
 // prepareChatModel shared logic to prepare chat model and options
 // it gets the chat model and sets up the chat options based on the chat manage.
 func prepareChatModel(ctx context.Context, modelService interfaces.ModelService,
@@ -75,6 +90,15 @@ func prepareMessagesWithHistory(chatManage *types.ChatManage) []chat.Message {
 		"language": chatManage.Language,
 		"contexts": chatManage.RenderedContexts,
 	})
+	if citationsEnabled(chatManage) {
+		systemPrompt += "\n\n" + citationInstruction
+	}
+	if chatManage.MemoryProfile != "" {
+		systemPrompt += "\n\n" + chatManage.MemoryProfile
+	}
+	if chatManage.MemorySessionSummary != "" {
+		systemPrompt += "\n\n" + chatManage.MemorySessionSummary
+	}
 
 	chatMessages := []chat.Message{
 		{Role: "system", Content: systemPrompt},
@@ -84,7 +108,7 @@ func prepareMessagesWithHistory(chatManage *types.ChatManage) []chat.Message {
 
 	// Add current user message. Only include images when the chat model supports
 	// vision; non-vision models rely on the text description in UserContent.
-	userMsg := chat.Message{Role: "user", Content: chatManage.UserContent}
+	userMsg := chat.Message{Role: "user", Content: chatManage.UserContent + renderMemoryEpisodes(chatManage.MemoryEpisodes)}
 	if chatManage.ChatModelSupportsVision && len(chatManage.Images) > 0 {
 		userMsg.Images = chatManage.Images
 	}
@@ -93,6 +117,15 @@ func prepareMessagesWithHistory(chatManage *types.ChatManage) []chat.Message {
 	return chatMessages
 }
 
+// renderMemoryEpisodes renders the related memory episodes appended to the
+// user message.
+func renderMemoryEpisodes(episodes []string) string {
+	if len(episodes) == 0 {
+		return ""
+	}
+	return "\n\nRelevant Memory:\n" + strings.Join(episodes, "\n") + "\n"
+}
+
 // AppendHistoryMessages appends prior Q&A rounds in chronological order.
 // History is already filtered and truncated upstream by the load_history plugin.
 func AppendHistoryMessages(messages []chat.Message, history []*types.History) []chat.Message {
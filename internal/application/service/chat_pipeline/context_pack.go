@@ -0,0 +1,255 @@
+package chatpipeline
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	agenttoken "github.com/Tencent/WeKnora/internal/agent/token"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// contextPassageOverhead approximates the tokens of the <context> tag
+	// around each passage.
+	contextPassageOverhead = 8
+	// historyRoundOverhead approximates the message framing of a history
+	// round.
+	historyRoundOverhead = 8
+)
+
+// contextTokenCounter counts and truncates text in model tokens.
+// *agenttoken.Estimator implements it.
+type contextTokenCounter interface {
+	EstimateString(s string) int
+	Truncate(s string, maxTokens int) string
+}
+
+// PluginContextPack fits the context of the answering model into the
+// agent's token budget. The budget left after the system prompt and the
+// question is split between memory, retrieved chunks and history by the
+// agent's ratios, and each part is trimmed least relevant content first:
+// the lowest-scored chunks, the oldest history rounds and the least related
+// memory episodes.
+type PluginContextPack struct {
+	modelService interfaces.ModelService
+	estimators   sync.Map // model name -> *agenttoken.Estimator
+}
+
+// NewPluginContextPack creates a new context packing plugin and registers it
+// with the event manager.
+func NewPluginContextPack(eventManager *EventManager, modelService interfaces.ModelService) *PluginContextPack {
+	res := &PluginContextPack{modelService: modelService}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the list of event types this plugin responds to.
+func (p *PluginContextPack) ActivationEvents() []types.EventType {
+	return []types.EventType{types.CONTEXT_PACK}
+}
+
+// OnEvent trims chatManage's memory, MergeResult and History to the budget.
+func (p *PluginContextPack) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	counter := p.counter(ctx, chatManage.ChatModelID)
+	if counter == nil {
+		return next()
+	}
+	fields := packContext(ctx, counter, chatManage, types.EffectiveContextTokenBudget(chatManage.ContextTokenBudget))
+	fields["session_id"] = chatManage.SessionID
+	pipelineInfo(ctx, "ContextPack", "output", fields)
+	return next()
+}
+
+// counter returns the token estimator for the encoding of the chat model.
+func (p *PluginContextPack) counter(ctx context.Context, modelID string) contextTokenCounter {
+	var name string
+	if model, err := p.modelService.GetModelByID(ctx, modelID); err == nil && model != nil {
+		name = model.Name
+	}
+	if est, ok := p.estimators.Load(name); ok {
+		return est.(*agenttoken.Estimator)
+	}
+	est, err := agenttoken.NewEstimatorForModel(name)
+	if err != nil {
+		pipelineWarn(ctx, "ContextPack", "tokenizer_unavailable", map[string]interface{}{
+			"model": name,
+			"error": err.Error(),
+		})
+		return nil
+	}
+	p.estimators.Store(name, est)
+	return est
+}
+
+// packContext trims chatManage to budget tokens and returns the figures to
+// log.
+func packContext(ctx context.Context, counter contextTokenCounter,
+	chatManage *types.ChatManage, budget int,
+) map[string]interface{} {
+	fixed := counter.EstimateString(packedSystemPrompt(chatManage)) +
+		counter.EstimateString(chatManage.SummaryConfig.ContextTemplate) +
+		counter.EstimateString(packedUserInput(chatManage))
+
+	memoryNeed := memoryTokens(counter, chatManage)
+	chunkCosts := make([]int, len(chatManage.MergeResult))
+	chunkNeed := 0
+	for i, r := range chatManage.MergeResult {
+		chunkCosts[i] = counter.EstimateString(getEnrichedPassageForChat(ctx, r)) + contextPassageOverhead
+		chunkNeed += chunkCosts[i]
+	}
+	historyCosts := make([]int, len(chatManage.History))
+	historyNeed := 0
+	for i, h := range chatManage.History {
+		historyCosts[i] = counter.EstimateString(h.Query) + counter.EstimateString(h.Answer) + historyRoundOverhead
+		historyNeed += historyCosts[i]
+	}
+
+	memoryBudget, chunkBudget, historyBudget := chatManage.ContextBudgetRatios.Allocate(
+		budget-fixed, memoryNeed, chunkNeed, historyNeed)
+
+	droppedEpisodes := packMemory(counter, chatManage, memoryBudget)
+	droppedChunks, truncatedChunk := packChunks(ctx, counter, chatManage, chunkCosts, chunkBudget)
+	droppedRounds := packHistory(chatManage, historyCosts, historyBudget)
+
+	return map[string]interface{}{
+		"budget":           budget,
+		"fixed_tokens":     fixed,
+		"memory_tokens":    memoryNeed,
+		"chunk_tokens":     chunkNeed,
+		"history_tokens":   historyNeed,
+		"memory_budget":    memoryBudget,
+		"chunk_budget":     chunkBudget,
+		"history_budget":   historyBudget,
+		"dropped_episodes": droppedEpisodes,
+		"dropped_chunks":   droppedChunks,
+		"truncated_chunk":  truncatedChunk,
+		"dropped_rounds":   droppedRounds,
+	}
+}
+
+// packedSystemPrompt is the system prompt without its memory parts.
+func packedSystemPrompt(chatManage *types.ChatManage) string {
+	prompt := chatManage.SummaryConfig.Prompt
+	if chatManage.SystemPromptOverride != "" {
+		prompt = chatManage.SystemPromptOverride
+	}
+	if citationsEnabled(chatManage) {
+		prompt += "\n\n" + citationInstruction
+	}
+	return prompt
+}
+
+// packedUserInput is the user message without its retrieved contexts and
+// memory. The pure chat path has built it already.
+func packedUserInput(chatManage *types.ChatManage) string {
+	if chatManage.UserContent != "" {
+		return chatManage.UserContent
+	}
+	input := chatManage.Query + chatManage.QuotedContext + chatManage.ImageDescription
+	if len(chatManage.Attachments) > 0 {
+		input += chatManage.Attachments.BuildPrompt()
+	}
+	return input
+}
+
+func memoryTokens(counter contextTokenCounter, chatManage *types.ChatManage) int {
+	total := counter.EstimateString(chatManage.MemoryProfile) + counter.EstimateString(chatManage.MemorySessionSummary)
+	for _, ep := range chatManage.MemoryEpisodes {
+		total += counter.EstimateString(ep) + 1
+	}
+	return total
+}
+
+// packMemory drops the least related episodes, then truncates the session
+// summary and finally the profile until the memory fits budget. It returns
+// the number of episodes dropped.
+func packMemory(counter contextTokenCounter, chatManage *types.ChatManage, budget int) int {
+	dropped := 0
+	for len(chatManage.MemoryEpisodes) > 0 && memoryTokens(counter, chatManage) > budget {
+		chatManage.MemoryEpisodes = chatManage.MemoryEpisodes[:len(chatManage.MemoryEpisodes)-1]
+		dropped++
+	}
+	if memoryTokens(counter, chatManage) > budget {
+		chatManage.MemorySessionSummary = counter.Truncate(chatManage.MemorySessionSummary,
+			budget-counter.EstimateString(chatManage.MemoryProfile))
+	}
+	if memoryTokens(counter, chatManage) > budget {
+		chatManage.MemoryProfile = counter.Truncate(chatManage.MemoryProfile, budget)
+	}
+	return dropped
+}
+
+// packChunks drops the lowest-scored chunks of MergeResult until they fit
+// budget, keeping the order of the rest. The best chunk is truncated rather
+// than dropped when there is room for part of it. It returns the number of
+// chunks dropped and whether one was truncated.
+func packChunks(ctx context.Context, counter contextTokenCounter,
+	chatManage *types.ChatManage, costs []int, budget int,
+) (int, bool) {
+	results := chatManage.MergeResult
+	need := 0
+	for _, c := range costs {
+		need += c
+	}
+	if need <= budget {
+		return 0, false
+	}
+
+	order := make([]int, len(results))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return results[order[a]].Score < results[order[b]].Score })
+
+	drop := make([]bool, len(results))
+	dropped, truncated := 0, false
+	for n, i := range order {
+		if need <= budget {
+			break
+		}
+		r := results[i]
+		if n == len(order)-1 {
+			extra := costs[i] - contextPassageOverhead - counter.EstimateString(r.Content)
+			if room := budget - contextPassageOverhead - extra; room > 0 {
+				r.Content = counter.Truncate(r.Content, room)
+				truncated = true
+				break
+			}
+		}
+		drop[i] = true
+		dropped++
+		need -= costs[i]
+		traceExclusion(ctx, "context_pack", types.ChunkExclusionTokenBudget, r,
+			fmt.Sprintf("tokens=%d chunk_budget=%d", costs[i], budget))
+	}
+
+	kept := make([]*types.SearchResult, 0, len(results)-dropped)
+	for i, r := range results {
+		if !drop[i] {
+			kept = append(kept, r)
+		}
+	}
+	chatManage.MergeResult = kept
+	return dropped, truncated
+}
+
+// packHistory drops the oldest history rounds until the rest fit budget and
+// returns the number dropped.
+func packHistory(chatManage *types.ChatManage, costs []int, budget int) int {
+	need := 0
+	for _, c := range costs {
+		need += c
+	}
+	dropped := 0
+	for dropped < len(costs) && need > budget {
+		need -= costs[dropped]
+		dropped++
+	}
+	chatManage.History = chatManage.History[dropped:]
+	return dropped
+}
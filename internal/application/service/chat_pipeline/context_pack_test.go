@@ -0,0 +1,81 @@
+package chatpipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// runeCounter counts one token per rune.
+type runeCounter struct{}
+
+func (runeCounter) EstimateString(s string) int { return len([]rune(s)) }
+
+func (runeCounter) Truncate(s string, maxTokens int) string {
+	if runes := []rune(s); len(runes) > maxTokens {
+		return string(runes[:max(maxTokens, 0)])
+	}
+	return s
+}
+
+func TestPackContextWithinBudget(t *testing.T) {
+	cm := &types.ChatManage{}
+	cm.Query = "q"
+	cm.MergeResult = []*types.SearchResult{{ID: "a", Content: "aaa", Score: 0.5}}
+	cm.History = []*types.History{{Query: "hq", Answer: "ha"}}
+
+	fields := packContext(context.Background(), runeCounter{}, cm, 1000)
+
+	assert.Len(t, cm.MergeResult, 1)
+	assert.Len(t, cm.History, 1)
+	assert.Equal(t, 0, fields["dropped_chunks"])
+}
+
+func TestPackChunksDropsLeastRelevantFirst(t *testing.T) {
+	cm := &types.ChatManage{}
+	cm.MergeResult = []*types.SearchResult{
+		{ID: "mid", Content: strings.Repeat("m", 92), Score: 0.5},
+		{ID: "low", Content: strings.Repeat("l", 92), Score: 0.1},
+		{ID: "high", Content: strings.Repeat("h", 92), Score: 0.9},
+	}
+	costs := []int{100, 100, 100}
+
+	dropped, truncated := packChunks(context.Background(), runeCounter{}, cm, costs, 250)
+
+	assert.Equal(t, 1, dropped)
+	assert.False(t, truncated)
+	assert.Equal(t, "mid", cm.MergeResult[0].ID)
+	assert.Equal(t, "high", cm.MergeResult[1].ID)
+
+	dropped, truncated = packChunks(context.Background(), runeCounter{}, cm, []int{100, 100}, 58)
+	assert.Equal(t, 1, dropped)
+	assert.True(t, truncated)
+	assert.Equal(t, "high", cm.MergeResult[0].ID)
+	assert.Equal(t, strings.Repeat("h", 50), cm.MergeResult[0].Content)
+}
+
+func TestPackHistoryDropsOldestRounds(t *testing.T) {
+	cm := &types.ChatManage{}
+	cm.History = []*types.History{{Query: "1"}, {Query: "2"}, {Query: "3"}}
+
+	assert.Equal(t, 2, packHistory(cm, []int{40, 30, 20}, 25))
+	assert.Equal(t, "3", cm.History[0].Query)
+}
+
+func TestPackMemory(t *testing.T) {
+	cm := &types.ChatManage{}
+	cm.MemoryProfile = strings.Repeat("p", 10)
+	cm.MemorySessionSummary = strings.Repeat("s", 20)
+	cm.MemoryEpisodes = []string{strings.Repeat("1", 9), strings.Repeat("2", 9)}
+
+	assert.Equal(t, 1, packMemory(runeCounter{}, cm, 45))
+	assert.Equal(t, []string{strings.Repeat("1", 9)}, cm.MemoryEpisodes)
+
+	assert.Equal(t, 1, packMemory(runeCounter{}, cm, 15))
+	assert.Empty(t, cm.MemoryEpisodes)
+	assert.Equal(t, strings.Repeat("s", 5), cm.MemorySessionSummary)
+	assert.Equal(t, strings.Repeat("p", 10), cm.MemoryProfile)
+}
@@ -0,0 +1,217 @@
+package chatpipeline
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// PluginContextWindow stitches the chunks around each retrieved chunk into
+// the answer context, as configured per knowledge base by
+// ChunkingConfig.ContextWindow.
+type PluginContextWindow struct {
+	knowledgeBaseService interfaces.KnowledgeBaseService
+	chunkRepo            interfaces.ChunkRepository
+}
+
+// NewPluginContextWindow creates a new context window plugin and registers
+// it with the event manager.
+func NewPluginContextWindow(eventManager *EventManager,
+	knowledgeBaseService interfaces.KnowledgeBaseService,
+	chunkRepo interfaces.ChunkRepository,
+) *PluginContextWindow {
+	res := &PluginContextWindow{
+		knowledgeBaseService: knowledgeBaseService,
+		chunkRepo:            chunkRepo,
+	}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the list of event types this plugin responds to.
+func (p *PluginContextWindow) ActivationEvents() []types.EventType {
+	return []types.EventType{types.CHUNK_CONTEXT_WINDOW}
+}
+
+// contextWindowGroup holds the hits of one knowledge to expand.
+type contextWindowGroup struct {
+	tenantID    uint64
+	knowledgeID string
+	window      int
+	hits        []*types.SearchResult
+}
+
+// OnEvent expands the text chunks of chatManage.MergeResult with their
+// neighbours by chunk index within the same knowledge. Neighbours already in
+// the context, as a hit or as part of one, are not repeated.
+func (p *PluginContextWindow) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	if !chatManage.NeedsRetrieval() || len(chatManage.MergeResult) == 0 {
+		return next()
+	}
+	groups := p.groupHits(ctx, chatManage.MergeResult)
+	if len(groups) == 0 {
+		return next()
+	}
+
+	claimed := make(map[string]bool)
+	for _, r := range chatManage.MergeResult {
+		claimed[r.ID] = true
+		for _, id := range r.SubChunkID {
+			claimed[id] = true
+		}
+	}
+
+	expanded := 0
+	for _, g := range groups {
+		var indices []int
+		seen := make(map[int]bool)
+		for _, r := range g.hits {
+			for i := max(0, r.ChunkIndex-g.window); i <= r.ChunkIndex+g.window; i++ {
+				if i != r.ChunkIndex && !seen[i] {
+					seen[i] = true
+					indices = append(indices, i)
+				}
+			}
+		}
+		chunks, err := p.chunkRepo.ListChunksByKnowledgeIndices(ctx, g.tenantID, g.knowledgeID, indices)
+		if err != nil {
+			pipelineWarn(ctx, "ContextWindow", "list_chunks_failed", map[string]interface{}{
+				"knowledge_id": g.knowledgeID,
+				"error":        err.Error(),
+			})
+			continue
+		}
+		byIndex := make(map[int]*types.Chunk, len(chunks))
+		for _, c := range chunks {
+			byIndex[c.ChunkIndex] = c
+		}
+		for _, r := range g.hits {
+			beforeLen := runeLen(r.Content)
+			if added := expandWithContextWindow(r, byIndex, g.window, claimed); len(added) > 0 {
+				expanded++
+				pipelineInfo(ctx, "ContextWindow", "expand", map[string]interface{}{
+					"chunk_id":   r.ID,
+					"added_ids":  added,
+					"before_len": beforeLen,
+					"after_len":  runeLen(r.Content),
+				})
+			}
+		}
+	}
+
+	pipelineInfo(ctx, "ContextWindow", "output", map[string]interface{}{
+		"session_id":   chatManage.SessionID,
+		"expanded_cnt": expanded,
+	})
+	return next()
+}
+
+// groupHits groups the expandable hits by knowledge, keeping the context
+// order, for the knowledge bases with a context window.
+func (p *PluginContextWindow) groupHits(ctx context.Context,
+	results []*types.SearchResult,
+) []*contextWindowGroup {
+	var kbIDs []string
+	seenKB := make(map[string]bool)
+	for _, r := range results {
+		if isContextWindowCandidate(r) && !seenKB[r.KnowledgeBaseID] {
+			seenKB[r.KnowledgeBaseID] = true
+			kbIDs = append(kbIDs, r.KnowledgeBaseID)
+		}
+	}
+	if len(kbIDs) == 0 {
+		return nil
+	}
+	kbs, err := p.knowledgeBaseService.GetKnowledgeBasesByIDsOnly(ctx, kbIDs)
+	if err != nil {
+		pipelineWarn(ctx, "ContextWindow", "load_kbs_failed", map[string]interface{}{
+			"kb_ids": kbIDs,
+			"error":  err.Error(),
+		})
+		return nil
+	}
+	kbByID := make(map[string]*types.KnowledgeBase, len(kbs))
+	for _, kb := range kbs {
+		kbByID[kb.ID] = kb
+	}
+
+	var groups []*contextWindowGroup
+	byKnowledge := make(map[string]*contextWindowGroup)
+	for _, r := range results {
+		if !isContextWindowCandidate(r) {
+			continue
+		}
+		kb := kbByID[r.KnowledgeBaseID]
+		if kb == nil || kb.ChunkingConfig.EffectiveContextWindow() == 0 {
+			continue
+		}
+		g, ok := byKnowledge[r.KnowledgeID]
+		if !ok {
+			g = &contextWindowGroup{
+				tenantID:    kb.TenantID,
+				knowledgeID: r.KnowledgeID,
+				window:      kb.ChunkingConfig.EffectiveContextWindow(),
+			}
+			byKnowledge[r.KnowledgeID] = g
+			groups = append(groups, g)
+		}
+		g.hits = append(g.hits, r)
+	}
+	return groups
+}
+
+// isContextWindowCandidate reports whether a hit is a plain document text
+// chunk. Parent-child hits already carry their parent as context.
+func isContextWindowCandidate(r *types.SearchResult) bool {
+	return r != nil && r.ChunkType == string(types.ChunkTypeText) && r.ParentChunkID == "" &&
+		r.KnowledgeID != "" && r.KnowledgeBaseID != ""
+}
+
+// expandWithContextWindow prepends and appends to r the chunks up to window
+// positions before and after it. Chunks r already covers are skipped; the
+// walk in a direction stops at a missing chunk or at one claimed by another
+// hit, so the context stays contiguous and free of repeats. It returns the
+// IDs of the chunks added.
+func expandWithContextWindow(r *types.SearchResult, byIndex map[int]*types.Chunk,
+	window int, claimed map[string]bool,
+) []string {
+	walk := func(step int) []*types.Chunk {
+		var out []*types.Chunk
+		for i := 1; i <= window; i++ {
+			c := byIndex[r.ChunkIndex+step*i]
+			if c == nil {
+				break
+			}
+			if containsID(r.SubChunkID, c.ID) {
+				continue
+			}
+			if claimed[c.ID] {
+				break
+			}
+			out = append(out, c)
+		}
+		return out
+	}
+	before := walk(-1)
+	after := walk(1)
+
+	var added []string
+	for _, c := range before {
+		r.Content = concatNoOverlap(c.Content, r.Content)
+		r.StartAt = c.StartAt
+		added = append(added, c.ID)
+	}
+	for _, c := range after {
+		r.Content = concatNoOverlap(r.Content, c.Content)
+		r.EndAt = max(r.EndAt, c.EndAt)
+		added = append(added, c.ID)
+	}
+	for _, id := range added {
+		claimed[id] = true
+		r.SubChunkID = append(r.SubChunkID, id)
+	}
+	return added
+}
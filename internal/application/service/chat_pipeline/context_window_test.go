@@ -0,0 +1,52 @@
+package chatpipeline
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpandWithContextWindow(t *testing.T) {
+	chunk := func(index int, content string) *types.Chunk {
+		return &types.Chunk{
+			ID: content, ChunkIndex: index, Content: content,
+			StartAt: index * 10, EndAt: index*10 + 10,
+		}
+	}
+	byIndex := map[int]*types.Chunk{
+		1: chunk(1, "c1"),
+		2: chunk(2, "c2"),
+		3: chunk(3, "c3"),
+		5: chunk(5, "c5"),
+		6: chunk(6, "c6"),
+		7: chunk(7, "c7"),
+	}
+
+	t.Run("stitches neighbours in order", func(t *testing.T) {
+		r := &types.SearchResult{ID: "c6", ChunkIndex: 6, Content: "c6", StartAt: 60, EndAt: 70}
+		added := expandWithContextWindow(r, byIndex, 1, map[string]bool{"c6": true})
+		assert.Equal(t, []string{"c5", "c7"}, added)
+		assert.Equal(t, "c5c6c7", r.Content)
+		assert.Equal(t, 50, r.StartAt)
+		assert.Equal(t, 80, r.EndAt)
+		assert.Equal(t, []string{"c5", "c7"}, r.SubChunkID)
+	})
+
+	t.Run("stops at gaps and claimed chunks, skips covered ones", func(t *testing.T) {
+		// c2 was already merged into the hit; c5 is another hit; index 4 is missing.
+		r := &types.SearchResult{
+			ID: "c3", ChunkIndex: 3, Content: "c2c3", StartAt: 20, EndAt: 40, SubChunkID: []string{"c2"},
+		}
+		claimed := map[string]bool{"c2": true, "c3": true, "c1": true}
+		added := expandWithContextWindow(r, byIndex, 2, claimed)
+		assert.Empty(t, added)
+		assert.Equal(t, "c2c3", r.Content)
+
+		delete(claimed, "c1")
+		added = expandWithContextWindow(r, byIndex, 2, claimed)
+		assert.Equal(t, []string{"c1"}, added)
+		assert.Equal(t, "c1c2c3", r.Content)
+		assert.True(t, claimed["c1"])
+	})
+}
@@ -119,7 +119,7 @@ If NO, leave the sql field empty.
 
 Return your response in the specified JSON format.`, chatManage.Query, knowledge.ID, schema.Description())
 
-	response, err := chatModel.Chat(ctx, []chat.Message{
+	response, err := chatModel.Chat(chat.WithResponseCache(ctx, "data_analysis_sql"), []chat.Message{
 		{Role: "user", Content: analysisPrompt},
 	}, &chat.ChatOptions{
 		Temperature: 0.1,
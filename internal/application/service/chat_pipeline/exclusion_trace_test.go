@@ -0,0 +1,52 @@
+package chatpipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/rerank"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestRemoveDuplicateResultsTracesSignatureDuplicates(t *testing.T) {
+	ctx, trace := types.WithRetrievalTrace(context.Background())
+	results := []*types.SearchResult{
+		{ID: "a", KnowledgeID: "k1", Content: "the same paragraph of text", Score: 0.9},
+		{ID: "a", KnowledgeID: "k1", Content: "the same paragraph of text", Score: 0.9},
+		{ID: "b", KnowledgeID: "k2", Content: "the same paragraph of text", Score: 0.7},
+	}
+
+	out := removeDuplicateResults(ctx, "merge", results)
+	if len(out) != 1 || out[0].ID != "a" {
+		t.Fatalf("unexpected dedup output: %+v", out)
+	}
+	got := trace.Exclusions()
+	if len(got) != 1 {
+		t.Fatalf("want 1 exclusion (repeated IDs are not traced), got %+v", got)
+	}
+	if got[0].ChunkID != "b" || got[0].Reason != types.ChunkExclusionDeduplicated || got[0].Stage != "merge" {
+		t.Fatalf("unexpected exclusion: %+v", got[0])
+	}
+}
+
+func TestTraceBelowThreshold(t *testing.T) {
+	ctx, trace := types.WithRetrievalTrace(context.Background())
+	candidates := []*types.SearchResult{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+
+	traceBelowThreshold(ctx, candidates, []rerank.RankResult{{Index: 1}}, 0.5)
+
+	got := trace.Exclusions()
+	if len(got) != 2 || got[0].ChunkID != "a" || got[1].ChunkID != "c" {
+		t.Fatalf("unexpected exclusions: %+v", got)
+	}
+	if got[0].Reason != types.ChunkExclusionBelowThreshold || got[0].Detail != "threshold=0.5000" {
+		t.Fatalf("unexpected exclusion: %+v", got[0])
+	}
+}
+
+func TestTraceExclusionWithoutTrace(t *testing.T) {
+	// Stages call the tracer unconditionally; it must be a no-op when the
+	// request did not ask for a debug trace.
+	traceExclusion(context.Background(), "rerank", types.ChunkExclusionTopK, &types.SearchResult{ID: "a"}, "")
+	traceNotSelected(context.Background(), []*types.SearchResult{{ID: "a"}}, nil)
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 	"regexp"
 	"strings"
 
@@ -57,8 +56,8 @@ func (p *PluginExtractEntity) ActivationEvents() []types.EventType {
 func (p *PluginExtractEntity) OnEvent(ctx context.Context,
 	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
 ) *PluginError {
-	if strings.ToLower(os.Getenv("NEO4J_ENABLE")) != "true" {
-		logger.Debugf(ctx, "skipping extract entity, neo4j is disabled")
+	if !types.GraphStoreEnabled() {
+		logger.Debugf(ctx, "skipping extract entity, graph store is disabled")
 		return next()
 	}
 
@@ -184,7 +183,7 @@ func (e *Extractor) Extract(ctx context.Context, content string) (*types.GraphDa
 	// logger.Debugf(ctx, "chat system: %s", generator.System(ctx))
 	// logger.Debugf(ctx, "chat user: %s", generator.User(ctx, content))
 
-	chatResponse, err := e.chat.Chat(ctx, generator.Render(ctx, content), e.chatOpt)
+	chatResponse, err := e.chat.Chat(chat.WithResponseCache(ctx, "entity_extraction"), generator.Render(ctx, content), e.chatOpt)
 	if err != nil {
 		logger.Errorf(ctx, "failed to chat: %v", err)
 		return nil, err
@@ -2,6 +2,7 @@ package chatpipeline
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/Tencent/WeKnora/internal/types"
 )
@@ -43,6 +44,9 @@ func (p *PluginFilterTopK) OnEvent(ctx context.Context,
 				"before": len(searchResult),
 				"after":  topK,
 			})
+			for _, r := range searchResult[topK:] {
+				traceExclusion(ctx, "filter_top_k", types.ChunkExclusionTopK, r, fmt.Sprintf("top_k=%d", topK))
+			}
 			searchResult = searchResult[:topK]
 		}
 		return searchResult
@@ -0,0 +1,152 @@
+package chatpipeline
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+const followUpPrompt = `Suggest %d follow-up questions the user may want to ask next in this conversation.
+%s
+Retrieved passages:
+%s
+
+Question: %s
+
+Answer:
+%s
+
+Each question must be answerable from the retrieved passages, must not repeat a question already asked, and should go deeper into or next to what the answer covers. Write them from the user's point of view, in the language of the question, each under 30 words.
+Output only the questions, one per line, without numbering.`
+
+const (
+	// followUpCount is the number of follow-up questions suggested.
+	followUpCount = 3
+	// followUpContextRunes caps the retrieved passages shown to the model.
+	followUpContextRunes = 6000
+	// followUpHistoryRounds is how many earlier questions are shown.
+	followUpHistoryRounds = 3
+)
+
+// followUpBullet matches list markers the model may put before a question.
+var followUpBullet = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)、．])\s*`)
+
+// followUpsEnabled reports whether follow-up questions are suggested after
+// the answer; they need retrieved passages to be grounded in.
+func followUpsEnabled(chatManage *types.ChatManage) bool {
+	return chatManage.FollowUpQuestionsEnabled && len(chatManage.CitationSources) > 0
+}
+
+// suggestFollowUps asks the query-understanding model (the chat model when
+// it is not set) for follow-up questions grounded in the retrieved passages
+// and the session history. Failures are logged and yield no questions.
+func suggestFollowUps(ctx context.Context, modelService interfaces.ModelService,
+	chatManage *types.ChatManage, answer string,
+) []string {
+	modelID := chatManage.ChatModelID
+	if chatManage.QueryUnderstandModelID != "" {
+		modelID = chatManage.QueryUnderstandModelID
+	}
+	model, err := modelService.GetChatModel(ctx, modelID)
+	if err != nil {
+		pipelineWarn(ctx, "FollowUp", "get_model", map[string]interface{}{
+			"session_id":    chatManage.SessionID,
+			"chat_model_id": modelID,
+			"error":         err.Error(),
+		})
+		return nil
+	}
+
+	thinking := false
+	response, err := model.Chat(ctx, []chat.Message{
+		{Role: "user", Content: buildFollowUpPrompt(chatManage, answer)},
+	}, &chat.ChatOptions{
+		Temperature:         0.7,
+		MaxCompletionTokens: 300,
+		Thinking:            &thinking,
+	})
+	if err != nil {
+		pipelineWarn(ctx, "FollowUp", "model_call", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return nil
+	}
+	questions := parseFollowUps(response.Content, chatManage)
+	pipelineInfo(ctx, "FollowUp", "output", map[string]interface{}{
+		"session_id":   chatManage.SessionID,
+		"question_cnt": len(questions),
+	})
+	return questions
+}
+
+func buildFollowUpPrompt(chatManage *types.ChatManage, answer string) string {
+	var earlier []string
+	for _, h := range chatManage.History[max(len(chatManage.History)-followUpHistoryRounds, 0):] {
+		if h != nil && h.Query != "" {
+			earlier = append(earlier, "- "+strings.Join(strings.Fields(h.Query), " "))
+		}
+	}
+	history := ""
+	if len(earlier) > 0 {
+		history = "\nEarlier questions in the conversation:\n" + strings.Join(earlier, "\n") + "\n"
+	}
+
+	passages := chatManage.RenderedContexts
+	if runes := []rune(passages); len(runes) > followUpContextRunes {
+		passages = string(runes[:followUpContextRunes]) + "\n..."
+	}
+	answer = strings.TrimSpace(regThinkTags.ReplaceAllString(answer, ""))
+	return fmt.Sprintf(followUpPrompt, followUpCount, history, passages, chatManage.Query, answer)
+}
+
+// parseFollowUps reads one question per line, dropping list markers, blank
+// lines, repeats of questions already asked and anything past followUpCount.
+func parseFollowUps(content string, chatManage *types.ChatManage) []string {
+	asked := map[string]bool{strings.TrimSpace(chatManage.Query): true}
+	for _, h := range chatManage.History {
+		if h != nil {
+			asked[strings.TrimSpace(h.Query)] = true
+		}
+	}
+	content = regThinkTags.ReplaceAllString(content, "")
+	var questions []string
+	for _, line := range strings.Split(content, "\n") {
+		q := strings.Trim(followUpBullet.ReplaceAllString(line, ""), " \t\r\"'“”")
+		if q == "" || asked[q] {
+			continue
+		}
+		asked[q] = true
+		questions = append(questions, q)
+		if len(questions) == followUpCount {
+			break
+		}
+	}
+	return questions
+}
+
+// emitFollowUps streams the suggested follow-up questions. It must run
+// before the final answer chunk, which completes the message.
+func emitFollowUps(ctx context.Context, chatManage *types.ChatManage, questions []string) {
+	if len(questions) == 0 || chatManage.EventBus == nil {
+		return
+	}
+	if err := chatManage.EventBus.Emit(ctx, types.Event{
+		ID:        fmt.Sprintf("%s-follow-ups", uuid.New().String()[:8]),
+		Type:      types.EventType(event.EventAgentFollowUps),
+		SessionID: chatManage.SessionID,
+		Data:      event.AgentFollowUpsData{Questions: questions},
+	}); err != nil {
+		pipelineWarn(ctx, "FollowUp", "emit_error", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package chatpipeline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFollowUps(t *testing.T) {
+	chatManage := &types.ChatManage{}
+	chatManage.Query = "How much leave do I get?"
+	chatManage.History = []*types.History{{Query: "Who approves leave?"}}
+
+	content := "<think>three questions</think>\n1. Does leave carry over?\r\n\n- Who approves leave?\n" +
+		"2) \"How much leave do I get?\"\n• Can I take half days?\n3、How is leave paid out?\nExtra question?"
+	assert.Equal(t, []string{
+		"Does leave carry over?",
+		"Can I take half days?",
+		"How is leave paid out?",
+	}, parseFollowUps(content, chatManage))
+
+	assert.Empty(t, parseFollowUps("\n\n", chatManage))
+}
+
+func TestBuildFollowUpPrompt(t *testing.T) {
+	chatManage := &types.ChatManage{}
+	chatManage.Query = "How much leave do I get?"
+	chatManage.RenderedContexts = strings.Repeat("x", followUpContextRunes+10)
+	for _, q := range []string{"q1", "q2", "q3", "q4"} {
+		chatManage.History = append(chatManage.History, &types.History{Query: q})
+	}
+
+	prompt := buildFollowUpPrompt(chatManage, "<think>hm</think>Ten days.")
+	assert.Contains(t, prompt, "Suggest 3 follow-up questions")
+	assert.Contains(t, prompt, "- q2\n- q3\n- q4\n")
+	assert.NotContains(t, prompt, "- q1")
+	assert.Contains(t, prompt, strings.Repeat("x", followUpContextRunes)+"\n...")
+	assert.Contains(t, prompt, "Answer:\nTen days.")
+
+	chatManage.History = nil
+	assert.NotContains(t, buildFollowUpPrompt(chatManage, "Ten days."), "Earlier questions")
+}
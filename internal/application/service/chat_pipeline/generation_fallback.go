@@ -0,0 +1,92 @@
+package chatpipeline
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// callWithFallbacks runs call on the chat model of the turn. When the model
+// is rate limited, times out or fails with a server error, the turn's
+// fallback models are tried in order, each with the messages adapted to it.
+// It returns the model call succeeded on and the messages sent to it, and
+// records the model in chatManage.AnswerMetadata.
+func callWithFallbacks(ctx context.Context, modelService interfaces.ModelService,
+	chatManage *types.ChatManage, primary chat.Chat, messages []chat.Message,
+	call func(chat.Chat, []chat.Message) error,
+) (chat.Chat, []chat.Message, error) {
+	err := call(primary, messages)
+	if err == nil {
+		chatManage.AnswerMetadata = &types.MessageMetadata{
+			ModelID:   primary.GetModelID(),
+			ModelName: primary.GetModelName(),
+		}
+		return primary, messages, nil
+	}
+	if len(chatManage.ChatFallbackModelIDs) == 0 || !chat.IsFailoverError(ctx, err) {
+		return nil, nil, err
+	}
+
+	// The messages were built for the primary model; without its record
+	// they are adapted as if it were from another provider.
+	primaryInfo, _ := modelService.GetModelByID(ctx, primary.GetModelID())
+	failed := primary.GetModelID()
+	tried := map[string]bool{failed: true}
+	for _, modelID := range chatManage.ChatFallbackModelIDs {
+		if tried[modelID] {
+			continue
+		}
+		tried[modelID] = true
+		pipelineWarn(ctx, "Generation", "model_failover", map[string]interface{}{
+			"failed_model": failed,
+			"next_model":   modelID,
+			"error":        err.Error(),
+		})
+		info, fallback, loadErr := loadChatModel(ctx, modelService, modelID)
+		if loadErr != nil {
+			pipelineWarn(ctx, "Generation", "get_fallback_model", map[string]interface{}{
+				"model_id": modelID,
+				"error":    loadErr.Error(),
+			})
+			continue
+		}
+		adapted := chat.AdaptMessages(messages, primaryInfo, info)
+		if err = call(fallback, adapted); err == nil {
+			chatManage.AnswerMetadata = &types.MessageMetadata{
+				ModelID:      fallback.GetModelID(),
+				ModelName:    fallback.GetModelName(),
+				FallbackFrom: primary.GetModelID(),
+			}
+			pipelineInfo(ctx, "Generation", "model_failover_success", map[string]interface{}{
+				"model_id": modelID,
+			})
+			return fallback, adapted, nil
+		}
+		if !chat.IsFailoverError(ctx, err) {
+			return nil, nil, err
+		}
+		failed = modelID
+	}
+	return nil, nil, err
+}
+
+// loadChatModel returns the record and the client of a chat model.
+func loadChatModel(ctx context.Context, modelService interfaces.ModelService,
+	modelID string,
+) (*types.Model, chat.Chat, error) {
+	info, err := modelService.GetModelByID(ctx, modelID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if info == nil {
+		return nil, nil, fmt.Errorf("model %s not found", modelID)
+	}
+	chatModel, err := modelService.GetChatModel(ctx, modelID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return info, chatModel, nil
+}
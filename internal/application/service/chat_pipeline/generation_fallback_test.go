@@ -0,0 +1,100 @@
+package chatpipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingChat is a chat model whose calls fail with err.
+type failingChat struct {
+	id  string
+	err error
+}
+
+func (c *failingChat) Chat(context.Context, []chat.Message, *chat.ChatOptions) (*types.ChatResponse, error) {
+	return nil, c.err
+}
+
+func (c *failingChat) ChatStream(context.Context, []chat.Message, *chat.ChatOptions) (<-chan types.StreamResponse, error) {
+	return nil, c.err
+}
+
+func (c *failingChat) GetModelID() string   { return c.id }
+func (c *failingChat) GetModelName() string { return c.id + "-name" }
+
+// chatModelService serves the chat models of a test by ID.
+type chatModelService struct {
+	interfaces.ModelService
+	models map[string]*failingChat
+	infos  map[string]*types.Model
+}
+
+func (s *chatModelService) GetModelByID(_ context.Context, id string) (*types.Model, error) {
+	if info, ok := s.infos[id]; ok {
+		return info, nil
+	}
+	return nil, errors.New("model not found")
+}
+
+func (s *chatModelService) GetChatModel(_ context.Context, id string) (chat.Chat, error) {
+	return s.models[id], nil
+}
+
+func TestCallWithFallbacks(t *testing.T) {
+	overloaded := errors.New("API request failed with status 503: overloaded")
+	primary := &failingChat{id: "primary", err: overloaded}
+	svc := &chatModelService{
+		models: map[string]*failingChat{
+			"busy":   {id: "busy", err: errors.New("API request failed with status 429: slow down")},
+			"backup": {id: "backup"},
+		},
+		infos: map[string]*types.Model{
+			"primary": {ID: "primary", Source: types.ModelSourceOpenAI, Parameters: types.ModelParameters{SupportsVision: true}},
+			"busy":    {ID: "busy", Source: types.ModelSourceDeepseek},
+			"backup":  {ID: "backup", Source: types.ModelSourceDeepseek},
+		},
+	}
+	cm := &types.ChatManage{}
+	cm.ChatFallbackModelIDs = []string{"missing", "busy", "backup"}
+	messages := []chat.Message{{Role: "user", Content: "hi", Images: []string{"img"}}}
+
+	var called []string
+	call := func(model chat.Chat, msgs []chat.Message) error {
+		called = append(called, model.GetModelID())
+		_, err := model.Chat(context.Background(), msgs, nil)
+		return err
+	}
+	model, sent, err := callWithFallbacks(context.Background(), svc, cm, primary, messages, call)
+	require.NoError(t, err)
+	assert.Equal(t, "backup", model.GetModelID())
+	assert.Equal(t, []string{"primary", "busy", "backup"}, called)
+	assert.Nil(t, sent[0].Images, "messages are adapted to the fallback model")
+	assert.Equal(t, &types.MessageMetadata{ModelID: "backup", ModelName: "backup-name", FallbackFrom: "primary"},
+		cm.AnswerMetadata)
+}
+
+func TestCallWithFallbacks_noFailover(t *testing.T) {
+	svc := &chatModelService{models: map[string]*failingChat{"backup": {id: "backup"}}}
+	call := func(model chat.Chat, msgs []chat.Message) error {
+		_, err := model.Chat(context.Background(), msgs, nil)
+		return err
+	}
+
+	cm := &types.ChatManage{}
+	cm.ChatFallbackModelIDs = []string{"backup"}
+	badRequest := &failingChat{id: "primary", err: errors.New("API request failed with status 400: bad tool")}
+	_, _, err := callWithFallbacks(context.Background(), svc, cm, badRequest, nil, call)
+	assert.Equal(t, badRequest.err, err, "request errors are not retried")
+
+	model, _, err := callWithFallbacks(context.Background(), svc, cm, &failingChat{id: "primary"}, nil, call)
+	require.NoError(t, err)
+	assert.Equal(t, "primary", model.GetModelID())
+	assert.Equal(t, &types.MessageMetadata{ModelID: "primary", ModelName: "primary-name"}, cm.AnswerMetadata)
+}
@@ -0,0 +1,188 @@
+package chatpipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// PipelineHook is a deployment-specific step run at chat pipeline events
+// alongside the built-in plugins. Hooks are registered with RegisterHook
+// (compiled-in Go hooks, usually from an init function) or configured as
+// HTTP webhooks under conversation.hooks in config.yaml.
+type PipelineHook interface {
+	// Name identifies the hook in logs and errors
+	Name() string
+	// Run handles the event; it may read and modify chatManage and must
+	// return once ctx is done
+	Run(ctx context.Context, eventType types.EventType, chatManage *types.ChatManage) error
+}
+
+// HookPhase is when a hook runs relative to the plugins of its event
+type HookPhase string
+
+const (
+	// HookPhaseBefore runs the hook before the event's plugins
+	HookPhaseBefore HookPhase = "before"
+	// HookPhaseAfter runs the hook after the event's plugins succeeded
+	HookPhaseAfter HookPhase = "after"
+)
+
+// HookFailurePolicy is what the pipeline does when a hook fails or times out
+type HookFailurePolicy string
+
+const (
+	// HookFailureSkip logs the failure and continues the pipeline
+	HookFailureSkip HookFailurePolicy = "skip"
+	// HookFailureAbort fails the event, which ends the pipeline
+	HookFailureAbort HookFailurePolicy = "abort"
+)
+
+// defaultHookTimeout bounds a hook run when HookOptions.Timeout is not set.
+const defaultHookTimeout = 5 * time.Second
+
+// HookOptions configures where and how a hook runs
+type HookOptions struct {
+	// Events are the pipeline events the hook runs at
+	Events []types.EventType
+	// Phase defaults to HookPhaseAfter
+	Phase HookPhase
+	// Priority orders the hooks of an event and phase, lowest first; hooks
+	// of equal priority run in registration order
+	Priority int
+	// Timeout bounds each run, defaultHookTimeout when zero
+	Timeout time.Duration
+	// FailurePolicy defaults to HookFailureSkip
+	FailurePolicy HookFailurePolicy
+}
+
+// Validate checks the options and fills in their defaults.
+func (o *HookOptions) Validate() error {
+	if len(o.Events) == 0 {
+		return errors.New("at least one event is required")
+	}
+	switch o.Phase {
+	case "":
+		o.Phase = HookPhaseAfter
+	case HookPhaseBefore, HookPhaseAfter:
+	default:
+		return fmt.Errorf("unknown phase %q", o.Phase)
+	}
+	switch o.FailurePolicy {
+	case "":
+		o.FailurePolicy = HookFailureSkip
+	case HookFailureSkip, HookFailureAbort:
+	default:
+		return fmt.Errorf("unknown failure policy %q", o.FailurePolicy)
+	}
+	if o.Timeout < 0 {
+		return errors.New("timeout must not be negative")
+	}
+	if o.Timeout == 0 {
+		o.Timeout = defaultHookTimeout
+	}
+	return nil
+}
+
+// ErrHookFailed is returned for an event when a hook with the abort policy
+// fails
+var ErrHookFailed = &PluginError{
+	Description: "Pipeline hook failed",
+	ErrorType:   "hook_failed",
+}
+
+type registeredHook struct {
+	hook PipelineHook
+	opts HookOptions
+}
+
+var (
+	globalHooksMu sync.Mutex
+	globalHooks   []registeredHook
+)
+
+// RegisterHook registers a hook with every EventManager created afterwards.
+// Deployments compile their hooks in and call it from an init function.
+func RegisterHook(hook PipelineHook, opts HookOptions) error {
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("hook %s: %w", hook.Name(), err)
+	}
+	globalHooksMu.Lock()
+	defer globalHooksMu.Unlock()
+	globalHooks = append(globalHooks, registeredHook{hook: hook, opts: opts})
+	return nil
+}
+
+// AddHook registers a hook with this EventManager.
+func (e *EventManager) AddHook(hook PipelineHook, opts HookOptions) error {
+	if err := opts.Validate(); err != nil {
+		return fmt.Errorf("hook %s: %w", hook.Name(), err)
+	}
+	e.addHook(registeredHook{hook: hook, opts: opts})
+	return nil
+}
+
+func (e *EventManager) addHook(h registeredHook) {
+	if e.hooks == nil {
+		e.hooks = make(map[types.EventType][]registeredHook)
+	}
+	for _, eventType := range h.opts.Events {
+		hooks := append(e.hooks[eventType], h)
+		sort.SliceStable(hooks, func(i, j int) bool { return hooks[i].opts.Priority < hooks[j].opts.Priority })
+		e.hooks[eventType] = hooks
+	}
+}
+
+// runHooks runs the hooks of an event in the given phase. It stops at the
+// first failing hook with the abort policy.
+func (e *EventManager) runHooks(ctx context.Context, phase HookPhase,
+	eventType types.EventType, chatManage *types.ChatManage,
+) *PluginError {
+	for _, h := range e.hooks[eventType] {
+		if h.opts.Phase != phase {
+			continue
+		}
+		start := time.Now()
+		err := runHook(ctx, h, eventType, chatManage)
+		fields := map[string]interface{}{
+			"hook":        h.hook.Name(),
+			"event":       string(eventType),
+			"phase":       string(phase),
+			"duration_ms": time.Since(start).Milliseconds(),
+		}
+		if err == nil {
+			pipelineInfo(ctx, "Hook", "complete", fields)
+			continue
+		}
+		fields["error"] = err.Error()
+		fields["failure_policy"] = string(h.opts.FailurePolicy)
+		if h.opts.FailurePolicy == HookFailureAbort {
+			pipelineError(ctx, "Hook", "failed", fields)
+			return ErrHookFailed.WithError(fmt.Errorf("hook %s: %w", h.hook.Name(), err))
+		}
+		pipelineWarn(ctx, "Hook", "failed", fields)
+	}
+	return nil
+}
+
+// runHook runs one hook under its timeout, turning a panic into an error.
+func runHook(ctx context.Context, h registeredHook, eventType types.EventType,
+	chatManage *types.ChatManage,
+) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, h.opts.Timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	if err = h.hook.Run(ctx, eventType, chatManage); err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,164 @@
+package chatpipeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// funcHook runs fn under the given name.
+type funcHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (h *funcHook) Name() string { return h.name }
+
+func (h *funcHook) Run(ctx context.Context, _ types.EventType, _ *types.ChatManage) error {
+	return h.fn(ctx)
+}
+
+// recordPlugin records its runs at CHUNK_RERANK.
+type recordPlugin struct {
+	order *[]string
+	err   *PluginError
+}
+
+func (p *recordPlugin) ActivationEvents() []types.EventType {
+	return []types.EventType{types.CHUNK_RERANK}
+}
+
+func (p *recordPlugin) OnEvent(_ context.Context, _ types.EventType, _ *types.ChatManage,
+	next func() *PluginError,
+) *PluginError {
+	*p.order = append(*p.order, "plugin")
+	if p.err != nil {
+		return p.err
+	}
+	return next()
+}
+
+func recordingHook(name string, order *[]string, err error) *funcHook {
+	return &funcHook{name: name, fn: func(context.Context) error {
+		*order = append(*order, name)
+		return err
+	}}
+}
+
+func TestEventManager_hookOrder(t *testing.T) {
+	var order []string
+	e := NewEventManager()
+	e.Register(&recordPlugin{order: &order})
+	events := []types.EventType{types.CHUNK_RERANK}
+	require.NoError(t, e.AddHook(recordingHook("after", &order, nil), HookOptions{Events: events}))
+	require.NoError(t, e.AddHook(recordingHook("before-2", &order, nil),
+		HookOptions{Events: events, Phase: HookPhaseBefore, Priority: 2}))
+	require.NoError(t, e.AddHook(recordingHook("before-1", &order, nil),
+		HookOptions{Events: events, Phase: HookPhaseBefore, Priority: 1}))
+	require.NoError(t, e.AddHook(recordingHook("other-event", &order, nil),
+		HookOptions{Events: []types.EventType{types.CHUNK_MERGE}}))
+
+	assert.Nil(t, e.Trigger(context.Background(), types.CHUNK_RERANK, &types.ChatManage{}))
+	assert.Equal(t, []string{"before-1", "before-2", "plugin", "after"}, order)
+}
+
+func TestEventManager_hookFailurePolicy(t *testing.T) {
+	var order []string
+	e := NewEventManager()
+	e.Register(&recordPlugin{order: &order})
+	events := []types.EventType{types.CHUNK_RERANK}
+	require.NoError(t, e.AddHook(recordingHook("skipped", &order, errors.New("boom")),
+		HookOptions{Events: events, Phase: HookPhaseBefore}))
+	require.NoError(t, e.AddHook(&funcHook{name: "panics", fn: func(context.Context) error { panic("oops") }},
+		HookOptions{Events: events, Phase: HookPhaseBefore, Priority: 1}))
+	require.NoError(t, e.AddHook(recordingHook("aborts", &order, errors.New("denied")),
+		HookOptions{Events: events, Phase: HookPhaseAfter, FailurePolicy: HookFailureAbort}))
+
+	err := e.Trigger(context.Background(), types.CHUNK_RERANK, &types.ChatManage{})
+	require.NotNil(t, err)
+	assert.Equal(t, ErrHookFailed.ErrorType, err.ErrorType)
+	assert.ErrorContains(t, err.Err, "aborts: denied")
+	assert.Equal(t, []string{"skipped", "plugin", "aborts"}, order)
+}
+
+func TestEventManager_afterHooksSkippedOnPluginError(t *testing.T) {
+	var order []string
+	e := NewEventManager()
+	e.Register(&recordPlugin{order: &order, err: ErrSearchNothing})
+	require.NoError(t, e.AddHook(recordingHook("after", &order, nil),
+		HookOptions{Events: []types.EventType{types.CHUNK_RERANK}}))
+
+	assert.Equal(t, ErrSearchNothing, e.Trigger(context.Background(), types.CHUNK_RERANK, &types.ChatManage{}))
+	assert.Equal(t, []string{"plugin"}, order)
+}
+
+func TestEventManager_hookTimeout(t *testing.T) {
+	e := NewEventManager()
+	slow := &funcHook{name: "slow", fn: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+	require.NoError(t, e.AddHook(slow, HookOptions{
+		Events:        []types.EventType{types.CHUNK_RERANK},
+		Timeout:       10 * time.Millisecond,
+		FailurePolicy: HookFailureAbort,
+	}))
+
+	err := e.Trigger(context.Background(), types.CHUNK_RERANK, &types.ChatManage{})
+	require.NotNil(t, err)
+	assert.ErrorIs(t, err.Err, context.DeadlineExceeded)
+}
+
+func TestHookOptions_Validate(t *testing.T) {
+	opts := HookOptions{Events: []types.EventType{types.CHUNK_RERANK}}
+	require.NoError(t, opts.Validate())
+	assert.Equal(t, HookPhaseAfter, opts.Phase)
+	assert.Equal(t, HookFailureSkip, opts.FailurePolicy)
+	assert.Equal(t, defaultHookTimeout, opts.Timeout)
+
+	for name, o := range map[string]HookOptions{
+		"no events":  {},
+		"bad phase":  {Events: opts.Events, Phase: "during"},
+		"bad policy": {Events: opts.Events, FailurePolicy: "retry"},
+		"negative":   {Events: opts.Events, Timeout: -time.Second},
+	} {
+		assert.Error(t, o.Validate(), name)
+	}
+}
+
+func TestWebhookHook(t *testing.T) {
+	var got WebhookPayload
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Token"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	hook, err := NewWebhookHook("audit", srv.URL, map[string]string{"X-Token": "secret"})
+	require.NoError(t, err)
+	cm := &types.ChatManage{}
+	cm.SessionID = "s1"
+	cm.Query = "How much leave?"
+	cm.RerankResult = []*types.SearchResult{{ID: "c1"}}
+
+	require.NoError(t, hook.Run(context.Background(), types.CHUNK_RERANK, cm))
+	assert.Equal(t, types.CHUNK_RERANK, got.Event)
+	assert.Equal(t, "s1", got.SessionID)
+	assert.Equal(t, 1, got.RerankResultCount)
+
+	status = http.StatusForbidden
+	assert.ErrorContains(t, hook.Run(context.Background(), types.CHUNK_RERANK, cm), "403")
+
+	_, err = NewWebhookHook("bad", "ftp://example.com", nil)
+	assert.Error(t, err)
+}
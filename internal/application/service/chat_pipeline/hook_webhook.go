@@ -0,0 +1,126 @@
+package chatpipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// WebhookHook posts a snapshot of the pipeline state to an HTTP endpoint.
+// A non-2xx response is a hook failure.
+type WebhookHook struct {
+	name    string
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// NewWebhookHook creates a webhook hook posting to rawURL
+func NewWebhookHook(name, rawURL string, headers map[string]string) (*WebhookHook, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook url %q", rawURL)
+	}
+	return &WebhookHook{name: name, url: rawURL, headers: headers, client: &http.Client{}}, nil
+}
+
+// Name returns the configured hook name
+func (h *WebhookHook) Name() string { return h.name }
+
+// WebhookPayload is the JSON body posted to a webhook hook
+type WebhookPayload struct {
+	Event             types.EventType   `json:"event"`
+	SessionID         string            `json:"session_id"`
+	UserID            string            `json:"user_id,omitempty"`
+	TenantID          uint64            `json:"tenant_id"`
+	MessageID         string            `json:"message_id,omitempty"`
+	Query             string            `json:"query"`
+	RewriteQuery      string            `json:"rewrite_query,omitempty"`
+	Intent            types.QueryIntent `json:"intent,omitempty"`
+	KnowledgeBaseIDs  []string          `json:"knowledge_base_ids,omitempty"`
+	SearchResultCount int               `json:"search_result_count"`
+	RerankResultCount int               `json:"rerank_result_count"`
+	MergeResultCount  int               `json:"merge_result_count"`
+	Answer            string            `json:"answer,omitempty"`
+}
+
+// Run posts the payload of the event and waits for the response.
+func (h *WebhookHook) Run(ctx context.Context, eventType types.EventType, chatManage *types.ChatManage) error {
+	payload := WebhookPayload{
+		Event:             eventType,
+		SessionID:         chatManage.SessionID,
+		UserID:            chatManage.UserID,
+		TenantID:          chatManage.TenantID,
+		MessageID:         chatManage.MessageID,
+		Query:             chatManage.Query,
+		RewriteQuery:      chatManage.RewriteQuery,
+		Intent:            chatManage.Intent,
+		KnowledgeBaseIDs:  chatManage.KnowledgeBaseIDs,
+		SearchResultCount: len(chatManage.SearchResult),
+		RerankResultCount: len(chatManage.RerankResult),
+		MergeResultCount:  len(chatManage.MergeResult),
+	}
+	if chatManage.ChatResponse != nil {
+		payload.Answer = chatManage.ChatResponse.Content
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range h.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// RegisterConfiguredHooks adds the webhook hooks of conversation.hooks in
+// config.yaml to the event manager.
+func RegisterConfiguredHooks(eventManager *EventManager, cfg *config.Config) error {
+	if cfg.Conversation == nil {
+		return nil
+	}
+	for _, hc := range cfg.Conversation.Hooks {
+		hook, err := NewWebhookHook(hc.Name, hc.URL, hc.Headers)
+		if err != nil {
+			return fmt.Errorf("pipeline hook %s: %w", hc.Name, err)
+		}
+		events := make([]types.EventType, 0, len(hc.Events))
+		for _, e := range hc.Events {
+			events = append(events, types.EventType(e))
+		}
+		if err := eventManager.AddHook(hook, HookOptions{
+			Events:        events,
+			Phase:         HookPhase(hc.Phase),
+			Priority:      hc.Priority,
+			Timeout:       time.Duration(hc.TimeoutMs) * time.Millisecond,
+			FailurePolicy: HookFailurePolicy(hc.FailurePolicy),
+		}); err != nil {
+			return fmt.Errorf("pipeline %w", err)
+		}
+		logger.Infof(context.Background(), "Registered pipeline webhook hook %s at %v", hc.Name, hc.Events)
+	}
+	return nil
+}
@@ -13,12 +13,15 @@ import (
 
 // PluginIntoChatMessage handles the transformation of search results into chat messages
 type PluginIntoChatMessage struct {
-	messageService interfaces.MessageService
+	messageService  interfaces.MessageService
+	glossaryService interfaces.GlossaryService
 }
 
 // NewPluginIntoChatMessage creates and registers a new PluginIntoChatMessage instance
-func NewPluginIntoChatMessage(eventManager *EventManager, messageService interfaces.MessageService) *PluginIntoChatMessage {
-	res := &PluginIntoChatMessage{messageService: messageService}
+func NewPluginIntoChatMessage(eventManager *EventManager,
+	messageService interfaces.MessageService, glossaryService interfaces.GlossaryService,
+) *PluginIntoChatMessage {
+	res := &PluginIntoChatMessage{messageService: messageService, glossaryService: glossaryService}
 	eventManager.Register(res)
 	return res
 }
@@ -77,7 +80,8 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 
 	// Intent-based no-search path: no retrieval results, but still render
 	// through the context template so runtime metadata (current_time, etc.) is injected.
-	if !chatManage.NeedsRetrieval() {
+	// Questions answered by the Text2SQL stage carry its result in MergeResult.
+	if !chatManage.NeedsRetrieval() && chatManage.Intent != types.IntentDataQuery {
 		userContent := safeQuery
 		if rewrite := strings.TrimSpace(chatManage.RewriteQuery); rewrite != "" {
 			if safeRewrite, ok := utils.ValidateInput(rewrite); ok {
@@ -129,16 +133,21 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 		contextsBuilder.WriteString(docHeader)
 		contextsBuilder.WriteString("\n")
 	}
+	if glossary := p.buildGlossaryBlock(ctx, chatManage); glossary != "" {
+		contextsBuilder.WriteString(glossary)
+		contextsBuilder.WriteString("\n")
+	}
 
-	// Build contexts string based on FAQ priority strategy
+	// Build contexts string based on FAQ priority strategy. Contexts are
+	// numbered in prompt order so the answer can cite the n-th one as [n].
 	if chatManage.FAQPriorityEnabled && len(faqResults) > 0 {
 		contextsBuilder.WriteString("<source type=\"faq\" priority=\"high\">\n")
 		for i, result := range faqResults {
 			passage := getEnrichedPassageForChat(ctx, result)
 			if hasHighConfidenceFAQ && i == 0 {
-				contextsBuilder.WriteString(fmt.Sprintf("<context id=\"FAQ-%d\" match=\"exact\">%s</context>\n", i+1, passage))
+				contextsBuilder.WriteString(fmt.Sprintf("<context id=\"%d\" match=\"exact\">%s</context>\n", i+1, passage))
 			} else {
-				contextsBuilder.WriteString(fmt.Sprintf("<context id=\"FAQ-%d\">%s</context>\n", i+1, passage))
+				contextsBuilder.WriteString(fmt.Sprintf("<context id=\"%d\">%s</context>\n", i+1, passage))
 			}
 		}
 		contextsBuilder.WriteString("</source>\n")
@@ -147,7 +156,7 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 			contextsBuilder.WriteString("<source type=\"document\" priority=\"supplementary\">\n")
 			for i, result := range docResults {
 				passage := getEnrichedPassageForChat(ctx, result)
-				contextsBuilder.WriteString(fmt.Sprintf("<context id=\"DOC-%d\">%s</context>\n", i+1, passage))
+				contextsBuilder.WriteString(fmt.Sprintf("<context id=\"%d\">%s</context>\n", len(faqResults)+i+1, passage))
 			}
 			contextsBuilder.WriteString("</source>")
 		}
@@ -162,6 +171,7 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 	}
 
 	chatManage.RenderedContexts = contextsBuilder.String()
+	chatManage.CitationSources = allResults
 
 	// Replace placeholders in context template
 	userContent := types.RenderPromptPlaceholders(chatManage.SummaryConfig.ContextTemplate, types.PlaceholderValues{
@@ -198,6 +208,37 @@ func (p *PluginIntoChatMessage) OnEvent(ctx context.Context,
 	return next()
 }
 
+// buildGlossaryBlock renders the KB glossary entries whose term occurs in the
+// user's query, so the model reads domain acronyms and jargon the way the
+// knowledge base defines them. Lookup failures only skip the block.
+func (p *PluginIntoChatMessage) buildGlossaryBlock(ctx context.Context, chatManage *types.ChatManage) string {
+	if p.glossaryService == nil || len(chatManage.SearchTargets) == 0 {
+		return ""
+	}
+	entries, err := p.glossaryService.MatchGlossary(ctx, chatManage.SearchTargets, chatManage.Query)
+	if err != nil {
+		pipelineWarn(ctx, "IntoChatMessage", "glossary_lookup_failed", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return ""
+	}
+	if len(entries) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<glossary>\n")
+	for _, e := range entries {
+		b.WriteString(fmt.Sprintf("<term name=%q>%s</term>\n", e.Term, e.Definition))
+	}
+	b.WriteString("</glossary>")
+	pipelineInfo(ctx, "IntoChatMessage", "glossary", map[string]interface{}{
+		"session_id": chatManage.SessionID,
+		"terms":      len(entries),
+	})
+	return b.String()
+}
+
 // persistRenderedContent asynchronously writes the RAG-augmented UserContent back
 // to the user message so that subsequent conversation turns can see the full
 // retrieval context in history.
@@ -0,0 +1,450 @@
+package chatpipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const kbRoutePrompt = `You route a user's question to the knowledge bases most likely to contain
+the answer. The knowledge bases are listed below with their descriptions.
+
+Knowledge bases:
+%s
+Question: %s
+
+Answer with a JSON array of the numbers of the relevant knowledge bases,
+most relevant first, at most %d, e.g. [2, 5]. Answer [] if none is relevant.
+Output only the array.`
+
+const (
+	// defaultKBRouteMaxKBs is the number of knowledge bases a question is
+	// routed to when the agent does not set one.
+	defaultKBRouteMaxKBs = 3
+	// kbRouteMinShortlist is the smallest number of knowledge bases the
+	// embedding pre-filter hands to the LLM classifier.
+	kbRouteMinShortlist = 10
+	// kbRouteMaxCachedProfiles bounds the knowledge base profile embedding
+	// cache; it is simply reset when full.
+	kbRouteMaxCachedProfiles = 2000
+	// kbRouteDescriptionRunes caps the description length shown to the
+	// classifier and embedded as the knowledge base profile.
+	kbRouteDescriptionRunes = 300
+)
+
+// kbRouteCandidate is a knowledge base the question may be routed to.
+type kbRouteCandidate struct {
+	target *types.SearchTarget
+	kb     *types.KnowledgeBase
+	score  float64
+	scored bool
+}
+
+// kbProfileEmbedding is a cached embedding of a knowledge base profile.
+type kbProfileEmbedding struct {
+	profile string
+	vector  []float32
+}
+
+// PluginKBRoute picks, for each question, the knowledge bases worth
+// searching when an agent resolves to many of them. The knowledge base
+// names and descriptions are first ranked against the question by
+// embedding similarity, then the chat model picks the relevant ones from
+// that shortlist. Any failure keeps the embedding ranking, or all
+// knowledge bases when nothing could be ranked.
+type PluginKBRoute struct {
+	knowledgeBaseService interfaces.KnowledgeBaseService
+	modelService         interfaces.ModelService
+
+	mu       sync.Mutex
+	profiles map[string]kbProfileEmbedding
+}
+
+// NewPluginKBRoute creates a new knowledge base routing plugin and
+// registers it with the event manager.
+func NewPluginKBRoute(eventManager *EventManager,
+	knowledgeBaseService interfaces.KnowledgeBaseService, modelService interfaces.ModelService,
+) *PluginKBRoute {
+	res := &PluginKBRoute{
+		knowledgeBaseService: knowledgeBaseService,
+		modelService:         modelService,
+		profiles:             make(map[string]kbProfileEmbedding),
+	}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the list of event types this plugin responds to.
+func (p *PluginKBRoute) ActivationEvents() []types.EventType {
+	return []types.EventType{types.KB_ROUTE}
+}
+
+// OnEvent narrows chatManage.SearchTargets and KnowledgeBaseIDs to the
+// routed knowledge bases. Targets limited to specific files are kept.
+func (p *PluginKBRoute) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	if !chatManage.KBAutoRoute || !chatManage.NeedsRetrieval() {
+		return next()
+	}
+	maxKBs := chatManage.KBRouteMaxKBs
+	if maxKBs <= 0 {
+		maxKBs = defaultKBRouteMaxKBs
+	}
+	candidates := p.loadCandidates(ctx, chatManage.SearchTargets)
+	if len(candidates) <= maxKBs {
+		pipelineInfo(ctx, "KBRoute", "skip", map[string]interface{}{
+			"session_id":    chatManage.SessionID,
+			"candidate_cnt": len(candidates),
+			"max_kbs":       maxKBs,
+		})
+		return next()
+	}
+	query := chatManage.RewriteQuery
+	if query == "" {
+		query = chatManage.Query
+	}
+
+	spanCtx, span := langfuse.GetManager().StartSpan(ctx, langfuse.SpanOptions{
+		Name: "kb_route",
+		Input: map[string]interface{}{
+			"query":         query,
+			"candidate_cnt": len(candidates),
+			"max_kbs":       maxKBs,
+		},
+	})
+	p.scoreCandidates(spanCtx, chatManage, query, candidates)
+	rankKBRouteCandidates(candidates)
+	shortlist := candidates[:min(len(candidates), max(maxKBs*3, kbRouteMinShortlist))]
+
+	selected, method := p.classify(spanCtx, chatManage, query, shortlist, maxKBs)
+	if len(selected) == 0 {
+		if !shortlist[0].scored {
+			// Nothing could be ranked: search everything rather than guess.
+			span.Finish(map[string]interface{}{"method": "none"}, nil, nil)
+			pipelineWarn(ctx, "KBRoute", "unranked", map[string]interface{}{
+				"session_id":    chatManage.SessionID,
+				"candidate_cnt": len(candidates),
+			})
+			return next()
+		}
+		selected, method = shortlist[:maxKBs], "embedding"
+	}
+	applyKBRoute(chatManage, candidates, selected)
+
+	routed := make([]map[string]interface{}, 0, len(selected))
+	for _, c := range selected {
+		routed = append(routed, map[string]interface{}{
+			"knowledge_base_id": c.kb.ID,
+			"name":              c.kb.Name,
+			"score":             c.score,
+		})
+	}
+	span.Finish(map[string]interface{}{
+		"method":   method,
+		"selected": routed,
+	}, nil, nil)
+	pipelineInfo(ctx, "KBRoute", "output", map[string]interface{}{
+		"session_id":    chatManage.SessionID,
+		"method":        method,
+		"candidate_cnt": len(candidates),
+		"selected":      routed,
+	})
+	return next()
+}
+
+// loadCandidates returns the whole-knowledge-base targets with their
+// knowledge bases. Targets whose knowledge base cannot be loaded are left
+// out of routing and always searched.
+func (p *PluginKBRoute) loadCandidates(ctx context.Context, targets types.SearchTargets) []*kbRouteCandidate {
+	ids := make([]string, 0, len(targets))
+	for _, t := range targets {
+		if t != nil && t.Type == types.SearchTargetTypeKnowledgeBase {
+			ids = append(ids, t.KnowledgeBaseID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	kbs, err := p.knowledgeBaseService.GetKnowledgeBasesByIDsOnly(ctx, ids)
+	if err != nil {
+		pipelineWarn(ctx, "KBRoute", "load_kbs", map[string]interface{}{
+			"kb_cnt": len(ids),
+			"error":  err.Error(),
+		})
+		return nil
+	}
+	byID := make(map[string]*types.KnowledgeBase, len(kbs))
+	for _, kb := range kbs {
+		if kb != nil {
+			byID[kb.ID] = kb
+		}
+	}
+	candidates := make([]*kbRouteCandidate, 0, len(ids))
+	for _, t := range targets {
+		if t == nil || t.Type != types.SearchTargetTypeKnowledgeBase {
+			continue
+		}
+		if kb := byID[t.KnowledgeBaseID]; kb != nil {
+			candidates = append(candidates, &kbRouteCandidate{target: t, kb: kb})
+		}
+	}
+	return candidates
+}
+
+// scoreCandidates scores each candidate by the cosine similarity between
+// the question and the knowledge base profile, in the knowledge base's own
+// embedding space. Candidates whose embedding model fails stay unscored.
+func (p *PluginKBRoute) scoreCandidates(ctx context.Context,
+	chatManage *types.ChatManage, query string, candidates []*kbRouteCandidate,
+) {
+	type modelKey struct {
+		modelID  string
+		tenantID uint64
+	}
+	groups := make(map[modelKey][]*kbRouteCandidate)
+	var keys []modelKey
+	for _, c := range candidates {
+		if c.kb.EmbeddingModelID == "" {
+			continue
+		}
+		key := modelKey{modelID: c.kb.EmbeddingModelID, tenantID: c.target.TenantID}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], c)
+	}
+
+	for _, key := range keys {
+		group := groups[key]
+		embedder, err := p.modelService.GetEmbeddingModelForTenant(ctx, key.modelID, key.tenantID)
+		if err != nil {
+			pipelineWarn(ctx, "KBRoute", "get_embedder", map[string]interface{}{
+				"session_id": chatManage.SessionID,
+				"model_id":   key.modelID,
+				"error":      err.Error(),
+			})
+			continue
+		}
+		queryVec, err := embedder.Embed(ctx, query)
+		if err != nil {
+			pipelineWarn(ctx, "KBRoute", "embed_query", map[string]interface{}{
+				"session_id": chatManage.SessionID,
+				"model_id":   key.modelID,
+				"error":      err.Error(),
+			})
+			continue
+		}
+
+		vectors := make([][]float32, len(group))
+		var missing []int
+		var missingProfiles []string
+		for i, c := range group {
+			profile := kbRouteProfile(c.kb)
+			if vec, ok := p.cachedProfile(key.modelID, c.kb.ID, profile); ok {
+				vectors[i] = vec
+				continue
+			}
+			missing = append(missing, i)
+			missingProfiles = append(missingProfiles, profile)
+		}
+		if len(missing) > 0 {
+			embedded, err := embedder.BatchEmbed(ctx, missingProfiles)
+			if err != nil || len(embedded) != len(missing) {
+				pipelineWarn(ctx, "KBRoute", "embed_profiles", map[string]interface{}{
+					"session_id": chatManage.SessionID,
+					"model_id":   key.modelID,
+					"kb_cnt":     len(missing),
+					"error":      fmt.Sprint(err),
+				})
+				continue
+			}
+			for j, i := range missing {
+				vectors[i] = embedded[j]
+				p.cacheProfile(key.modelID, group[i].kb.ID, missingProfiles[j], embedded[j])
+			}
+		}
+		for i, c := range group {
+			c.score = kbRouteCosine(queryVec, vectors[i])
+			c.scored = true
+		}
+	}
+}
+
+// classify asks the chat model which shortlisted knowledge bases are
+// relevant. It returns nil when the model is unavailable or picks none.
+func (p *PluginKBRoute) classify(ctx context.Context, chatManage *types.ChatManage,
+	query string, shortlist []*kbRouteCandidate, maxKBs int,
+) ([]*kbRouteCandidate, string) {
+	modelID := chatManage.ChatModelID
+	if chatManage.QueryUnderstandModelID != "" {
+		modelID = chatManage.QueryUnderstandModelID
+	}
+	model, err := p.modelService.GetChatModel(ctx, modelID)
+	if err != nil {
+		pipelineWarn(ctx, "KBRoute", "get_model", map[string]interface{}{
+			"session_id":    chatManage.SessionID,
+			"chat_model_id": modelID,
+			"error":         err.Error(),
+		})
+		return nil, ""
+	}
+
+	var list strings.Builder
+	for i, c := range shortlist {
+		fmt.Fprintf(&list, "[%d] %s", i+1, c.kb.Name)
+		if desc := kbRouteDescription(c.kb); desc != "" {
+			fmt.Fprintf(&list, ": %s", desc)
+		}
+		list.WriteString("\n")
+	}
+	thinking := false
+	response, err := model.Chat(ctx, []chat.Message{
+		{Role: "user", Content: fmt.Sprintf(kbRoutePrompt, list.String(), query, maxKBs)},
+	}, &chat.ChatOptions{
+		Temperature:         0.1,
+		MaxCompletionTokens: 50,
+		Thinking:            &thinking,
+	})
+	if err != nil {
+		pipelineWarn(ctx, "KBRoute", "model_call", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return nil, ""
+	}
+
+	var selected []*kbRouteCandidate
+	seen := make(map[int]bool)
+	for _, n := range parseKBRouteSelection(response.Content) {
+		if n < 1 || n > len(shortlist) || seen[n] {
+			continue
+		}
+		seen[n] = true
+		selected = append(selected, shortlist[n-1])
+		if len(selected) == maxKBs {
+			break
+		}
+	}
+	return selected, "llm"
+}
+
+func (p *PluginKBRoute) cachedProfile(modelID, kbID, profile string) ([]float32, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	cached, ok := p.profiles[modelID+"|"+kbID]
+	if !ok || cached.profile != profile {
+		return nil, false
+	}
+	return cached.vector, true
+}
+
+func (p *PluginKBRoute) cacheProfile(modelID, kbID, profile string, vector []float32) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.profiles) >= kbRouteMaxCachedProfiles {
+		p.profiles = make(map[string]kbProfileEmbedding)
+	}
+	p.profiles[modelID+"|"+kbID] = kbProfileEmbedding{profile: profile, vector: vector}
+}
+
+// applyKBRoute drops the candidate knowledge bases that were not selected.
+// File-level targets and knowledge bases left out of routing are kept, and
+// the order is preserved.
+func applyKBRoute(chatManage *types.ChatManage, candidates, selected []*kbRouteCandidate) {
+	drop := make(map[string]bool, len(candidates))
+	for _, c := range candidates {
+		drop[c.kb.ID] = true
+	}
+	for _, c := range selected {
+		drop[c.kb.ID] = false
+	}
+	targets := make(types.SearchTargets, 0, len(chatManage.SearchTargets))
+	for _, t := range chatManage.SearchTargets {
+		if t == nil {
+			continue
+		}
+		if t.Type == types.SearchTargetTypeKnowledgeBase && drop[t.KnowledgeBaseID] {
+			continue
+		}
+		targets = append(targets, t)
+	}
+	chatManage.SearchTargets = targets
+
+	kbIDs := make([]string, 0, len(chatManage.KnowledgeBaseIDs))
+	for _, id := range chatManage.KnowledgeBaseIDs {
+		if !drop[id] {
+			kbIDs = append(kbIDs, id)
+		}
+	}
+	chatManage.KnowledgeBaseIDs = kbIDs
+}
+
+// rankKBRouteCandidates sorts scored candidates by descending score,
+// followed by the unscored ones in their original order.
+func rankKBRouteCandidates(candidates []*kbRouteCandidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].scored != candidates[j].scored {
+			return candidates[i].scored
+		}
+		return candidates[i].score > candidates[j].score
+	})
+}
+
+// parseKBRouteSelection reads the first JSON array of numbers in content.
+func parseKBRouteSelection(content string) []int {
+	content = regThinkTags.ReplaceAllString(content, "")
+	start := strings.Index(content, "[")
+	end := strings.LastIndex(content, "]")
+	if start < 0 || end < start {
+		return nil
+	}
+	var numbers []int
+	if err := json.Unmarshal([]byte(content[start:end+1]), &numbers); err != nil {
+		return nil
+	}
+	return numbers
+}
+
+// kbRouteProfile is the text a knowledge base is matched on.
+func kbRouteProfile(kb *types.KnowledgeBase) string {
+	if desc := kbRouteDescription(kb); desc != "" {
+		return kb.Name + "\n" + desc
+	}
+	return kb.Name
+}
+
+func kbRouteDescription(kb *types.KnowledgeBase) string {
+	desc := strings.Join(strings.Fields(kb.Description), " ")
+	if runes := []rune(desc); len(runes) > kbRouteDescriptionRunes {
+		desc = string(runes[:kbRouteDescriptionRunes]) + "..."
+	}
+	return desc
+}
+
+// kbRouteCosine returns the cosine of two vectors, or 0 when their lengths
+// differ or either is zero.
+func kbRouteCosine(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,70 @@
+package chatpipeline
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKBRouteSelection(t *testing.T) {
+	assert.Equal(t, []int{2, 5}, parseKBRouteSelection("[2, 5]"))
+	assert.Equal(t, []int{1}, parseKBRouteSelection("<think>kb [3]?</think>The answer: [1]"))
+	assert.Equal(t, []int{}, parseKBRouteSelection("[]"))
+	assert.Nil(t, parseKBRouteSelection("none"))
+	assert.Nil(t, parseKBRouteSelection(`["a"]`))
+}
+
+func TestKBRouteCosine(t *testing.T) {
+	assert.InDelta(t, 1.0, kbRouteCosine([]float32{1, 2}, []float32{2, 4}), 1e-9)
+	assert.InDelta(t, 0.0, kbRouteCosine([]float32{1, 0}, []float32{0, 1}), 1e-9)
+	assert.Equal(t, 0.0, kbRouteCosine([]float32{1}, []float32{1, 2}))
+	assert.Equal(t, 0.0, kbRouteCosine([]float32{0, 0}, []float32{1, 2}))
+}
+
+func TestRankKBRouteCandidates(t *testing.T) {
+	kb := func(id string) *types.KnowledgeBase { return &types.KnowledgeBase{ID: id} }
+	candidates := []*kbRouteCandidate{
+		{kb: kb("unscored-1")},
+		{kb: kb("low"), score: 0.2, scored: true},
+		{kb: kb("unscored-2")},
+		{kb: kb("high"), score: 0.9, scored: true},
+	}
+
+	rankKBRouteCandidates(candidates)
+
+	var ids []string
+	for _, c := range candidates {
+		ids = append(ids, c.kb.ID)
+	}
+	assert.Equal(t, []string{"high", "low", "unscored-1", "unscored-2"}, ids)
+}
+
+func TestApplyKBRoute(t *testing.T) {
+	kbTarget := func(id string) *types.SearchTarget {
+		return &types.SearchTarget{Type: types.SearchTargetTypeKnowledgeBase, KnowledgeBaseID: id}
+	}
+	fileTarget := &types.SearchTarget{
+		Type: types.SearchTargetTypeKnowledge, KnowledgeBaseID: "kb-files", KnowledgeIDs: []string{"k1"},
+	}
+	cm := &types.ChatManage{}
+	cm.SearchTargets = types.SearchTargets{kbTarget("hr"), kbTarget("it"), fileTarget, kbTarget("legal"), kbTarget("unloaded")}
+	cm.KnowledgeBaseIDs = []string{"hr", "it", "legal", "unloaded"}
+	candidates := []*kbRouteCandidate{
+		{kb: &types.KnowledgeBase{ID: "hr"}},
+		{kb: &types.KnowledgeBase{ID: "it"}},
+		{kb: &types.KnowledgeBase{ID: "legal"}},
+	}
+
+	applyKBRoute(cm, candidates, candidates[2:])
+
+	assert.Equal(t, types.SearchTargets{fileTarget, kbTarget("legal"), kbTarget("unloaded")}, cm.SearchTargets)
+	assert.Equal(t, []string{"legal", "unloaded"}, cm.KnowledgeBaseIDs)
+}
+
+func TestKBRouteProfile(t *testing.T) {
+	assert.Equal(t, "HR", kbRouteProfile(&types.KnowledgeBase{Name: "HR"}))
+	assert.Equal(t, "HR\nLeave and payroll policies", kbRouteProfile(&types.KnowledgeBase{
+		Name: "HR", Description: "  Leave and \n  payroll policies ",
+	}))
+}
@@ -3,8 +3,11 @@ package chatpipeline
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
+	"sync/atomic"
 
+	"github.com/Tencent/WeKnora/internal/application/service/memory"
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -13,11 +16,17 @@ import (
 
 type MemoryPlugin struct {
 	memoryService interfaces.MemoryService
+	taskEnqueuer  interfaces.TaskEnqueuer
 }
 
-func NewMemoryPlugin(eventManager *EventManager, memoryService interfaces.MemoryService) *MemoryPlugin {
+func NewMemoryPlugin(
+	eventManager *EventManager,
+	memoryService interfaces.MemoryService,
+	taskEnqueuer interfaces.TaskEnqueuer,
+) *MemoryPlugin {
 	res := &MemoryPlugin{
 		memoryService: memoryService,
+		taskEnqueuer:  taskEnqueuer,
 	}
 	eventManager.Register(res)
 	return res
@@ -63,6 +72,23 @@ func (p *MemoryPlugin) handleRetrieval(
 		query = chatManage.Query
 	}
 
+	// Stable facts about the user go into the system prompt
+	profile, err := p.memoryService.GetUserProfile(ctx, chatManage.UserID)
+	if err != nil {
+		logger.Errorf(ctx, "failed to get user profile: %v", err)
+	} else {
+		chatManage.MemoryProfile = renderUserProfile(profile)
+	}
+
+	// Working memory: the summary of this session's earlier turns comes
+	// ahead of the long-term episodes
+	summary, err := p.memoryService.GetSessionSummary(ctx, chatManage.UserID, chatManage.SessionID)
+	if err != nil {
+		logger.Errorf(ctx, "failed to get session summary: %v", err)
+	} else {
+		chatManage.MemorySessionSummary = renderSessionSummary(summary)
+	}
+
 	memoryContext, err := p.memoryService.RetrieveMemory(ctx, chatManage.UserID, query)
 	if err != nil {
 		logger.Errorf(ctx, "failed to retrieve memory: %v", err)
@@ -70,14 +96,14 @@ func (p *MemoryPlugin) handleRetrieval(
 		return next()
 	}
 
-	// Add memory context to chatManage
-	if len(memoryContext.RelatedEpisodes) > 0 {
-		memoryStr := "\n\nRelevant Memory:\n"
-		for _, ep := range memoryContext.RelatedEpisodes {
-			memoryStr += fmt.Sprintf("- %s (Summary: %s)\n", ep.CreatedAt.Format("2006-01-02"), ep.Summary)
-		}
-		chatManage.UserContent += memoryStr
-		logger.Infof(ctx, "Retrieved memory: %s", memoryStr)
+	// Add memory context to chatManage; it is appended to the user message
+	chatManage.MemoryEpisodes = nil
+	for _, ep := range memoryContext.RelatedEpisodes {
+		chatManage.MemoryEpisodes = append(chatManage.MemoryEpisodes,
+			fmt.Sprintf("- %s (Summary: %s)", ep.CreatedAt.Format("2006-01-02"), ep.Summary))
+	}
+	if len(chatManage.MemoryEpisodes) > 0 {
+		logger.Infof(ctx, "Retrieved memory: %d episodes", len(chatManage.MemoryEpisodes))
 	}
 	logger.Info(ctx, "End to retrieve memory")
 
@@ -97,22 +123,16 @@ func (p *MemoryPlugin) handleStorage(
 	if !chatManage.EnableMemory {
 		return nil
 	}
+	// A stopped answer is incomplete; it is not stored
+	if ctx.Err() != nil {
+		logger.Info(ctx, "Generation cancelled, skip storing memory")
+		return nil
+	}
 
 	logger.Info(ctx, "Start to store memory")
 	// If ChatResponse is already available (non-streaming), store it directly
 	if chatManage.ChatResponse != nil {
-		messages := []types.Message{
-			{Role: "user", Content: chatManage.Query},
-			{Role: "assistant", Content: chatManage.ChatResponse.Content},
-		}
-		userID := chatManage.UserID
-		sessionID := chatManage.SessionID
-		bgCtx := context.WithoutCancel(ctx)
-		go func() {
-			if err := p.memoryService.AddEpisode(bgCtx, userID, sessionID, messages); err != nil {
-				logger.Errorf(bgCtx, "failed to add episode: %v", err)
-			}
-		}()
+		p.remember(ctx, chatManage, chatManage.ChatResponse.Content)
 		return nil
 	}
 
@@ -120,10 +140,15 @@ func (p *MemoryPlugin) handleStorage(
 	if chatManage.EventBus != nil {
 		var fullResponse string
 		var storeOnce sync.Once
-		userID := chatManage.UserID
-		sessionID := chatManage.SessionID
+		var cancelled atomic.Bool
 		bgCtx := context.WithoutCancel(ctx)
 
+		// The stop handler announces a cancelled generation on the bus
+		chatManage.EventBus.On(types.EventType(event.EventCancelled), func(context.Context, types.Event) error {
+			cancelled.Store(true)
+			return nil
+		})
+
 		chatManage.EventBus.On(types.EventType(event.EventAgentFinalAnswer), func(_ context.Context, evt types.Event) error {
 			data, ok := evt.Data.(event.AgentFinalAnswerData)
 			if !ok {
@@ -134,15 +159,11 @@ func (p *MemoryPlugin) handleStorage(
 				// Stream layer may emit Done:true twice (e.g. finish_reason chunk + EOF sentinel).
 				// qa.go dedupes with completionHandled; keep memory writes consistent with one episode only.
 				storeOnce.Do(func() {
-					messages := []types.Message{
-						{Role: "user", Content: chatManage.Query},
-						{Role: "assistant", Content: fullResponse},
+					// A stop cancels ctx; the answer so far is incomplete
+					if ctx.Err() != nil || cancelled.Load() {
+						return
 					}
-					go func() {
-						if err := p.memoryService.AddEpisode(bgCtx, userID, sessionID, messages); err != nil {
-							logger.Errorf(bgCtx, "failed to add episode: %v", err)
-						}
-					}()
+					p.remember(bgCtx, chatManage, fullResponse)
 				})
 			}
 			return nil
@@ -152,3 +173,86 @@ func (p *MemoryPlugin) handleStorage(
 
 	return nil
 }
+
+// remember queues the turn for memory extraction, which stores it as the
+// session's memory policy says and folds the session's overflowing turns
+// into its rolling summary. When the queue is unreachable the turn is
+// extracted in the background instead, without retries.
+func (p *MemoryPlugin) remember(ctx context.Context, chatManage *types.ChatManage, answer string) {
+	turnID := chatManage.UserMessageID
+	if turnID == "" {
+		turnID = chatManage.MessageID
+	}
+	payload := &types.MemoryExtractPayload{
+		UserID:    chatManage.UserID,
+		SessionID: chatManage.SessionID,
+		TurnID:    turnID,
+		Query:     chatManage.Query,
+		Answer:    answer,
+	}
+	payload.TenantID, _ = types.TenantIDFromContext(ctx)
+	p.applyPolicy(ctx, chatManage, payload)
+
+	err := memory.EnqueueExtraction(ctx, p.taskEnqueuer, payload)
+	if err == nil {
+		return
+	}
+	logger.Warnf(ctx, "failed to queue memory extraction, extracting inline: %v", err)
+	bgCtx := context.WithoutCancel(ctx)
+	go func() {
+		if err := memory.Extract(bgCtx, p.memoryService, payload); err != nil {
+			logger.Errorf(bgCtx, "failed to extract memory: %v", err)
+		}
+	}()
+}
+
+// applyPolicy sets how the turn is stored under the session's memory
+// policy. A turn the request asks to remember is always stored; turns the
+// policy skips still update the session's rolling summary.
+func (p *MemoryPlugin) applyPolicy(ctx context.Context, chatManage *types.ChatManage, payload *types.MemoryExtractPayload) {
+	if chatManage.Remember {
+		return
+	}
+	policy := chatManage.MemoryPolicy
+	switch policy.EffectiveTrigger() {
+	case types.MemoryTriggerEveryNTurns:
+		n := policy.BatchSize()
+		turns, err := p.memoryService.CountSessionTurns(ctx, chatManage.SessionID)
+		if err != nil {
+			logger.Warnf(ctx, "failed to count session turns: %v", err)
+			payload.SummaryOnly = true
+			return
+		}
+		if turns%n != 0 {
+			payload.SummaryOnly = true
+			return
+		}
+		payload.Trigger = types.MemoryTriggerEveryNTurns
+		payload.Turns = n
+	case types.MemoryTriggerMemorable:
+		payload.Trigger = types.MemoryTriggerMemorable
+	case types.MemoryTriggerSessionEnd, types.MemoryTriggerExplicit:
+		payload.SummaryOnly = true
+	}
+}
+
+// renderSessionSummary formats the session summary for the system prompt.
+func renderSessionSummary(summary *types.SessionSummary) string {
+	if summary == nil || summary.Summary == "" {
+		return ""
+	}
+	return "Summary of the earlier part of this conversation:\n" + summary.Summary
+}
+
+// renderUserProfile formats the profile facts for the system prompt.
+func renderUserProfile(profile *types.UserProfile) string {
+	if profile == nil || len(profile.Facts) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Known facts about the user, remembered from earlier conversations:\n")
+	for _, fact := range profile.Facts {
+		fmt.Fprintf(&b, "- %s (%s): %s\n", strings.ReplaceAll(fact.Key, "_", " "), fact.Category, fact.Value)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,165 @@
+package chatpipeline
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const memoryRewritePrompt = `You resolve references in a user's question so that it can be used on its
+own as a search query.
+
+Replace pronouns and vague references such as "it", "that one" or "the
+previous plan" with the entities they refer to, using the conversation and
+the user's memory below. Keep the language, wording and intent of the
+question; do not answer it or add information it does not ask about. If
+nothing needs resolving, repeat the question unchanged.
+
+Output only the rewritten question.
+
+User's memory:
+%s
+Conversation:
+%s
+Question: %s`
+
+var memoryRewriteThinkTags = regexp.MustCompile(`(?s)<think>.*?</think>`)
+
+// PluginMemoryRewrite resolves coreferences in the user's question against
+// the conversation memory before retrieval, e.g. "what was its price" after
+// talking about a MacBook becomes "what was the MacBook's price", so that
+// follow-up questions still match the documents they are about.
+type PluginMemoryRewrite struct {
+	modelService  interfaces.ModelService
+	memoryService interfaces.MemoryService
+}
+
+// NewPluginMemoryRewrite creates a new memory-aware query rewriting plugin
+// and registers it with the event manager.
+func NewPluginMemoryRewrite(eventManager *EventManager,
+	modelService interfaces.ModelService, memoryService interfaces.MemoryService,
+) *PluginMemoryRewrite {
+	res := &PluginMemoryRewrite{
+		modelService:  modelService,
+		memoryService: memoryService,
+	}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the list of event types this plugin responds to.
+func (p *PluginMemoryRewrite) ActivationEvents() []types.EventType {
+	return []types.EventType{types.MEMORY_QUERY_REWRITE}
+}
+
+// OnEvent rewrites chatManage.RewriteQuery. Any failure keeps the query as
+// it is; the pipeline is never blocked.
+func (p *PluginMemoryRewrite) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	if !chatManage.EnableMemory || !chatManage.NeedsRetrieval() {
+		return next()
+	}
+	query := chatManage.RewriteQuery
+	if query == "" {
+		query = chatManage.Query
+	}
+
+	memoryContext, err := p.memoryService.RetrieveMemory(ctx, chatManage.UserID, query)
+	if err != nil {
+		pipelineWarn(ctx, "MemoryRewrite", "retrieve_memory", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return next()
+	}
+	memoryText := renderMemoryForRewrite(memoryContext)
+	if memoryText == "" {
+		pipelineInfo(ctx, "MemoryRewrite", "skip", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"reason":     "no_memory",
+		})
+		return next()
+	}
+
+	modelID := chatManage.ChatModelID
+	if chatManage.QueryUnderstandModelID != "" {
+		modelID = chatManage.QueryUnderstandModelID
+	}
+	model, err := p.modelService.GetChatModel(ctx, modelID)
+	if err != nil {
+		pipelineError(ctx, "MemoryRewrite", "get_model", map[string]interface{}{
+			"session_id":    chatManage.SessionID,
+			"chat_model_id": modelID,
+			"error":         err.Error(),
+		})
+		return next()
+	}
+
+	conversation := formatConversationHistory(chatManage.History)
+	if conversation == "" {
+		conversation = "(none)\n"
+	}
+	thinking := false
+	response, err := model.Chat(ctx, []chat.Message{
+		{Role: "user", Content: fmt.Sprintf(memoryRewritePrompt, memoryText, conversation, query)},
+	}, &chat.ChatOptions{
+		Temperature:         0.1,
+		MaxCompletionTokens: 150,
+		Thinking:            &thinking,
+	})
+	if err != nil {
+		pipelineError(ctx, "MemoryRewrite", "model_call", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return next()
+	}
+
+	if rewrite := cleanMemoryRewrite(response.Content); rewrite != "" {
+		chatManage.RewriteQuery = rewrite
+	}
+	pipelineInfo(ctx, "MemoryRewrite", "output", map[string]interface{}{
+		"session_id":    chatManage.SessionID,
+		"query":         query,
+		"rewrite_query": chatManage.RewriteQuery,
+	})
+	return next()
+}
+
+// renderMemoryForRewrite lists the remembered entities, relationships and
+// episodes the rewrite may resolve references to. It is empty when nothing
+// relevant is remembered.
+func renderMemoryForRewrite(memoryContext *types.MemoryContext) string {
+	if memoryContext == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, entity := range memoryContext.RelatedEntities {
+		if entity.Description != "" {
+			fmt.Fprintf(&b, "- %s (%s): %s\n", entity.Title, entity.Type, entity.Description)
+		} else {
+			fmt.Fprintf(&b, "- %s (%s)\n", entity.Title, entity.Type)
+		}
+	}
+	for _, rel := range memoryContext.RelatedRelations {
+		fmt.Fprintf(&b, "- %s -> %s: %s\n", rel.Source, rel.Target, rel.Description)
+	}
+	for _, ep := range memoryContext.RelatedEpisodes {
+		fmt.Fprintf(&b, "- %s: %s\n", ep.CreatedAt.Format("2006-01-02"), ep.Summary)
+	}
+	return b.String()
+}
+
+// cleanMemoryRewrite strips reasoning, quotes and a "Question:" label the
+// model may have echoed from its answer.
+func cleanMemoryRewrite(raw string) string {
+	content := strings.TrimSpace(memoryRewriteThinkTags.ReplaceAllString(raw, ""))
+	content = strings.TrimSpace(strings.TrimPrefix(content, "Question:"))
+	return strings.TrimSpace(strings.Trim(content, "\"“”"))
+}
@@ -0,0 +1,51 @@
+package chatpipeline
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestRenderMemoryForRewrite(t *testing.T) {
+	if got := renderMemoryForRewrite(nil); got != "" {
+		t.Errorf("nil context: got %q, want empty", got)
+	}
+	if got := renderMemoryForRewrite(&types.MemoryContext{}); got != "" {
+		t.Errorf("empty context: got %q, want empty", got)
+	}
+
+	got := renderMemoryForRewrite(&types.MemoryContext{
+		RelatedEntities: []types.Entity{{Title: "MacBook Pro", Type: "Product", Description: "laptop the user is buying"}},
+		RelatedRelations: []types.Relationship{
+			{Source: "User", Target: "MacBook Pro", Description: "considers buying"},
+		},
+		RelatedEpisodes: []types.Episode{
+			{Summary: "User compared laptop prices", CreatedAt: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)},
+		},
+	})
+	for _, want := range []string{
+		"- MacBook Pro (Product): laptop the user is buying",
+		"- User -> MacBook Pro: considers buying",
+		"- 2025-03-01: User compared laptop prices",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("rendered memory %q does not contain %q", got, want)
+		}
+	}
+}
+
+func TestCleanMemoryRewrite(t *testing.T) {
+	cases := map[string]string{
+		"What was the MacBook's price?":                                "What was the MacBook's price?",
+		"  Question: What was the MacBook's price?\n":                  "What was the MacBook's price?",
+		"<think>it = MacBook</think>\"What was the MacBook's price?\"": "What was the MacBook's price?",
+		"": "",
+	}
+	for raw, want := range cases {
+		if got := cleanMemoryRewrite(raw); got != want {
+			t.Errorf("cleanMemoryRewrite(%q) = %q, want %q", raw, got, want)
+		}
+	}
+}
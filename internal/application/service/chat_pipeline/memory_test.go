@@ -0,0 +1,113 @@
+package chatpipeline
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// countingEnqueuer counts the memory extraction tasks queued.
+type countingEnqueuer struct {
+	mu    sync.Mutex
+	tasks int
+}
+
+func (e *countingEnqueuer) Enqueue(task *asynq.Task, _ ...asynq.Option) (*asynq.TaskInfo, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.tasks++
+	return &asynq.TaskInfo{ID: "task-1"}, nil
+}
+
+func (e *countingEnqueuer) count() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.tasks
+}
+
+func newMemoryStorageChat() *types.ChatManage {
+	chat := &types.ChatManage{}
+	chat.SessionID = "session-1"
+	chat.UserMessageID = "user-msg-1"
+	chat.Query = "How many days of annual leave?"
+	chat.EnableMemory = true
+	// Remember skips the session policy, which would count turns
+	chat.Remember = true
+	return chat
+}
+
+func TestMemoryStorageSkipsCancelledAnswer(t *testing.T) {
+	enqueuer := &countingEnqueuer{}
+	plugin := &MemoryPlugin{taskEnqueuer: enqueuer}
+	next := func() *PluginError { return nil }
+
+	// Non-streaming answer of a stopped run
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	chat := newMemoryStorageChat()
+	chat.ChatResponse = &types.ChatResponse{Content: "Annual leave is"}
+	if err := plugin.handleStorage(ctx, chat, next); err != nil {
+		t.Fatalf("handleStorage: %v", err)
+	}
+	if got := enqueuer.count(); got != 0 {
+		t.Fatalf("cancelled answer: %d memory tasks queued, want 0", got)
+	}
+
+	// Streaming answer stopped before it finished
+	ctx, cancel = context.WithCancel(context.Background())
+	bus := event.NewEventBus()
+	chat = newMemoryStorageChat()
+	chat.EventBus = bus.AsEventBusInterface()
+	if err := plugin.handleStorage(ctx, chat, next); err != nil {
+		t.Fatalf("handleStorage: %v", err)
+	}
+	emit := func(content string, done bool) {
+		_ = bus.Emit(ctx, event.Event{
+			Type: event.EventAgentFinalAnswer,
+			Data: event.AgentFinalAnswerData{Content: content, Done: done},
+		})
+	}
+	emit("Annual leave is", false)
+	cancel()
+	emit("", true)
+	if got := enqueuer.count(); got != 0 {
+		t.Fatalf("stopped stream: %d memory tasks queued, want 0", got)
+	}
+
+	// The cancelled event alone skips storage, e.g. when ctx outlives the stop
+	ctx = context.Background()
+	bus = event.NewEventBus()
+	chat = newMemoryStorageChat()
+	chat.EventBus = bus.AsEventBusInterface()
+	if err := plugin.handleStorage(ctx, chat, next); err != nil {
+		t.Fatalf("handleStorage: %v", err)
+	}
+	emit("Annual leave is", false)
+	_ = bus.Emit(ctx, event.Event{
+		Type: event.EventCancelled,
+		Data: event.CancelledData{SessionID: "session-1", MessageID: "msg-1"},
+	})
+	emit("", true)
+	if got := enqueuer.count(); got != 0 {
+		t.Fatalf("cancelled stream: %d memory tasks queued, want 0", got)
+	}
+
+	// A finished answer is stored once
+	ctx = context.Background()
+	bus = event.NewEventBus()
+	chat = newMemoryStorageChat()
+	chat.EventBus = bus.AsEventBusInterface()
+	if err := plugin.handleStorage(ctx, chat, next); err != nil {
+		t.Fatalf("handleStorage: %v", err)
+	}
+	emit("Annual leave is 15 days", true)
+	emit("", true)
+	if got := enqueuer.count(); got != 1 {
+		t.Fatalf("finished answer: %d memory tasks queued, want 1", got)
+	}
+}
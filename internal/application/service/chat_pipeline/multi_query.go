@@ -0,0 +1,269 @@
+package chatpipeline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const queryVariantsPrompt = `Write %d search queries that help find documents answering the question
+below, using these kinds of queries: %s.
+
+- paraphrase: the question reworded with different terms or synonyms.
+- sub_question: one part of a question that asks several things.
+- hyde: a short passage (2-4 sentences) that would answer the question, as
+  it might appear in a document. Plausible details are fine.
+
+Write in the language of the question and do not repeat it unchanged.
+Answer with a JSON array only, e.g.
+[{"type": "paraphrase", "query": "..."}, {"type": "hyde", "query": "..."}]
+
+Question: %s`
+
+const (
+	// multiQueryRRFK is the smoothing constant of the Reciprocal Rank Fusion
+	// of the per-query result lists.
+	multiQueryRRFK = 60
+	// queryVariantMaxRunes caps a variant, which keeps HyDE passages to the
+	// size of a chunk.
+	queryVariantMaxRunes = 500
+)
+
+// PluginQueryVariants generates the query variations retrieved alongside the
+// question in multi-query mode. Any failure leaves the variants empty, and
+// search falls back to the question alone.
+type PluginQueryVariants struct {
+	modelService interfaces.ModelService
+}
+
+// NewPluginQueryVariants creates a new query variants plugin and registers
+// it with the event manager.
+func NewPluginQueryVariants(eventManager *EventManager, modelService interfaces.ModelService) *PluginQueryVariants {
+	res := &PluginQueryVariants{modelService: modelService}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the list of event types this plugin responds to.
+func (p *PluginQueryVariants) ActivationEvents() []types.EventType {
+	return []types.EventType{types.QUERY_VARIANTS}
+}
+
+// OnEvent fills chatManage.QueryVariants.
+func (p *PluginQueryVariants) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	if !chatManage.MultiQueryEnabled || !chatManage.NeedsRetrieval() {
+		return next()
+	}
+	query := strings.TrimSpace(chatManage.RewriteQuery)
+	if query == "" {
+		return next()
+	}
+	count := types.EffectiveMultiQueryCount(chatManage.MultiQueryCount)
+	strategies := types.EffectiveMultiQueryStrategies(chatManage.MultiQueryStrategies)
+
+	modelID := chatManage.ChatModelID
+	if chatManage.QueryUnderstandModelID != "" {
+		modelID = chatManage.QueryUnderstandModelID
+	}
+	model, err := p.modelService.GetChatModel(ctx, modelID)
+	if err != nil {
+		pipelineError(ctx, "QueryVariants", "get_model", map[string]interface{}{
+			"session_id":    chatManage.SessionID,
+			"chat_model_id": modelID,
+			"error":         err.Error(),
+		})
+		return next()
+	}
+
+	names := make([]string, len(strategies))
+	for i, s := range strategies {
+		names[i] = string(s)
+	}
+	thinking := false
+	response, err := model.Chat(ctx, []chat.Message{
+		{Role: "user", Content: fmt.Sprintf(queryVariantsPrompt, count, strings.Join(names, ", "), query)},
+	}, &chat.ChatOptions{
+		Temperature:         0.7,
+		MaxCompletionTokens: 300 * count,
+		Thinking:            &thinking,
+	})
+	if err != nil {
+		pipelineError(ctx, "QueryVariants", "model_call", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return next()
+	}
+
+	chatManage.QueryVariants = parseQueryVariants(response.Content, query, strategies, count)
+	pipelineInfo(ctx, "QueryVariants", "output", map[string]interface{}{
+		"session_id": chatManage.SessionID,
+		"query":      query,
+		"variants":   chatManage.QueryVariants,
+	})
+	return next()
+}
+
+// parseQueryVariants reads the variants from the model's answer, keeping at
+// most count variants of the allowed types that differ from the question.
+func parseQueryVariants(content, query string,
+	strategies []types.QueryVariantType, count int,
+) []types.QueryVariant {
+	content = regThinkTags.ReplaceAllString(content, "")
+	start := strings.Index(content, "[")
+	end := strings.LastIndex(content, "]")
+	if start < 0 || end < start {
+		return nil
+	}
+	var parsed []types.QueryVariant
+	if err := json.Unmarshal([]byte(content[start:end+1]), &parsed); err != nil {
+		return nil
+	}
+
+	allowed := make(map[types.QueryVariantType]bool, len(strategies))
+	for _, s := range strategies {
+		allowed[s] = true
+	}
+	seen := map[string]bool{strings.ToLower(query): true}
+	var variants []types.QueryVariant
+	for _, v := range parsed {
+		v.Query = strings.TrimSpace(v.Query)
+		if runes := []rune(v.Query); len(runes) > queryVariantMaxRunes {
+			v.Query = string(runes[:queryVariantMaxRunes])
+		}
+		key := strings.ToLower(v.Query)
+		if !allowed[v.Type] || v.Query == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		variants = append(variants, v)
+		if len(variants) == count {
+			break
+		}
+	}
+	return variants
+}
+
+// retrievalQueries returns the queries the targets are searched with: the
+// question, its variants in multi-query mode and the description of the
+// images uploaded with the question. The description finds the chunks
+// derived from similar images (captions and OCR text), which the question
+// alone rarely matches.
+func retrievalQueries(chatManage *types.ChatManage) []string {
+	queries := make([]string, 0, len(chatManage.QueryVariants)+2)
+	queries = append(queries, chatManage.RewriteQuery)
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(chatManage.RewriteQuery)): true}
+	add := func(q string) {
+		q = strings.TrimSpace(q)
+		if runes := []rune(q); len(runes) > queryVariantMaxRunes {
+			q = string(runes[:queryVariantMaxRunes])
+		}
+		if key := strings.ToLower(q); q != "" && !seen[key] {
+			seen[key] = true
+			queries = append(queries, q)
+		}
+	}
+	for _, v := range chatManage.QueryVariants {
+		add(v.Query)
+	}
+	if len(chatManage.Images) > 0 {
+		add(chatManage.ImageDescription)
+	}
+	return queries
+}
+
+// searchMultiQuery searches the targets with each of the queries in parallel
+// and fuses the result lists with RRF. The fused list keeps at most twice
+// EmbeddingTopK chunks, so reranking costs about as much as for a single
+// query.
+func (p *PluginSearch) searchMultiQuery(
+	ctx context.Context, chatManage *types.ChatManage, queries []string,
+) []*types.SearchResult {
+	lists := make([][]*types.SearchResult, len(queries))
+	var wg sync.WaitGroup
+	for i, q := range queries {
+		wg.Add(1)
+		go func(i int, q string) {
+			defer wg.Done()
+			lists[i] = p.searchByTargets(ctx, chatManage, q)
+		}(i, q)
+	}
+	wg.Wait()
+
+	hits := make([]int, len(lists))
+	for i, list := range lists {
+		hits[i] = len(list)
+	}
+	fused := fuseMultiQueryResults(ctx, lists, multiQueryRRFK, chatManage.EmbeddingTopK*2)
+	pipelineInfo(ctx, "Search", "multi_query_fused", map[string]interface{}{
+		"queries":   len(queries),
+		"hits":      hits,
+		"fused_cnt": len(fused),
+	})
+	return fused
+}
+
+// fuseMultiQueryResults merges per-query result lists by Reciprocal Rank
+// Fusion: a chunk scores 1/(k+rank) in each list it appears in. Each chunk
+// keeps its best original score, which later stages threshold on; the RRF
+// score only orders the list and decides which chunks are kept when limit
+// is positive.
+func fuseMultiQueryResults(ctx context.Context,
+	lists [][]*types.SearchResult, k, limit int,
+) []*types.SearchResult {
+	type fusedResult struct {
+		result *types.SearchResult
+		rrf    float64
+	}
+	byID := make(map[string]*fusedResult)
+	var fused []*fusedResult
+	for _, list := range lists {
+		ranked := make([]*types.SearchResult, 0, len(list))
+		for _, r := range list {
+			if r != nil {
+				ranked = append(ranked, r)
+			}
+		}
+		sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+
+		seen := make(map[string]bool, len(ranked))
+		rank := 0
+		for _, r := range ranked {
+			if seen[r.ID] {
+				continue
+			}
+			seen[r.ID] = true
+			rank++
+			f, ok := byID[r.ID]
+			if !ok {
+				f = &fusedResult{result: r}
+				byID[r.ID] = f
+				fused = append(fused, f)
+			} else if r.Score > f.result.Score {
+				f.result = r
+			}
+			f.rrf += 1 / float64(k+rank)
+		}
+	}
+	sort.SliceStable(fused, func(i, j int) bool { return fused[i].rrf > fused[j].rrf })
+
+	out := make([]*types.SearchResult, 0, len(fused))
+	for i, f := range fused {
+		if limit > 0 && i >= limit {
+			traceExclusion(ctx, "search", types.ChunkExclusionTopK, f.result,
+				fmt.Sprintf("multi_query rrf=%.4f limit=%d", f.rrf, limit))
+			continue
+		}
+		out = append(out, f.result)
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package chatpipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseQueryVariants(t *testing.T) {
+	all := types.EffectiveMultiQueryStrategies(nil)
+	content := `<think>...</think>Here you go:
+[{"type": "paraphrase", "query": "annual leave days"},
+ {"type": "sub_question", "query": "How many leave days?"},
+ {"type": "hyde", "query": "Employees receive 10 days of annual leave."},
+ {"type": "other", "query": "ignored"},
+ {"type": "paraphrase", "query": "  "},
+ {"type": "paraphrase", "query": "How many leave days?"}]`
+
+	variants := parseQueryVariants(content, "how many leave days?", all, 3)
+
+	assert.Equal(t, []types.QueryVariant{
+		{Type: types.QueryVariantParaphrase, Query: "annual leave days"},
+		{Type: types.QueryVariantHyDE, Query: "Employees receive 10 days of annual leave."},
+	}, variants)
+
+	only := parseQueryVariants(content, "q", []types.QueryVariantType{types.QueryVariantHyDE}, 3)
+	assert.Len(t, only, 1)
+	assert.Equal(t, types.QueryVariantHyDE, only[0].Type)
+
+	assert.Len(t, parseQueryVariants(content, "q", all, 1), 1)
+	assert.Nil(t, parseQueryVariants("no json", "q", all, 3))
+}
+
+func TestFuseMultiQueryResults(t *testing.T) {
+	r := func(id string, score float64) *types.SearchResult {
+		return &types.SearchResult{ID: id, Score: score}
+	}
+	lists := [][]*types.SearchResult{
+		{r("a", 0.9), r("b", 0.8), r("c", 0.7)},
+		{r("c", 0.95), r("d", 0.6)},
+		{r("c", 0.5), r("b", 0.4), nil},
+	}
+
+	fused := fuseMultiQueryResults(context.Background(), lists, 60, 0)
+
+	ids := make([]string, 0, len(fused))
+	for _, f := range fused {
+		ids = append(ids, f.ID)
+	}
+	// c is found by all three queries, b by two, a and d by one each.
+	assert.Equal(t, []string{"c", "b", "a", "d"}, ids)
+	assert.Equal(t, 0.95, fused[0].Score, "keeps the best original score")
+
+	limited := fuseMultiQueryResults(context.Background(), lists, 60, 2)
+	assert.Len(t, limited, 2)
+}
+
+func TestRetrievalQueries(t *testing.T) {
+	cm := &types.ChatManage{}
+	cm.RewriteQuery = "what is this part?"
+	assert.Equal(t, []string{"what is this part?"}, retrievalQueries(cm))
+
+	// The description of an uploaded image is searched alongside the question.
+	cm.Images = []string{"data:image/png;base64,AAAA"}
+	cm.ImageDescription = "  A hydraulic pump with a red pressure valve.  "
+	cm.QueryVariants = []types.QueryVariant{
+		{Type: types.QueryVariantParaphrase, Query: "identify this component"},
+		{Type: types.QueryVariantParaphrase, Query: "What is this part?"},
+	}
+	assert.Equal(t, []string{
+		"what is this part?",
+		"identify this component",
+		"A hydraulic pump with a red pressure valve.",
+	}, retrievalQueries(cm))
+
+	// A description left over without images is ignored.
+	cm.Images = nil
+	assert.Len(t, retrievalQueries(cm), 2)
+}
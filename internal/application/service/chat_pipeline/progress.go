@@ -31,13 +31,14 @@ func ShouldEmitQueryUnderstandProgress(chatManage *types.ChatManage) bool {
 }
 
 // IsConsolidatedRetrievalStage reports whether a pipeline stage belongs to the
-// single user-visible "knowledge search" progress window (search → rerank → merge).
+// single user-visible "knowledge search" progress window (route → search → rerank → merge).
 func IsConsolidatedRetrievalStage(stage types.EventType, chatManage *types.ChatManage) bool {
 	if chatManage == nil {
 		return false
 	}
 	switch stage {
-	case types.CHUNK_SEARCH_PARALLEL, types.CHUNK_RERANK, types.CHUNK_MERGE, types.FILTER_TOP_K:
+	case types.KB_ROUTE, types.QUERY_VARIANTS, types.CHUNK_SEARCH_PARALLEL, types.CHUNK_RERANK,
+		types.CHUNK_MERGE, types.FILTER_TOP_K, types.CHUNK_CONTEXT_WINDOW:
 		return chatManage.NeedsRetrieval()
 	case types.WEB_FETCH:
 		return chatManage.WebSearchEnabled
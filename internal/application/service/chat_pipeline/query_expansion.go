@@ -63,7 +63,7 @@ func (p *PluginSearch) runQueryExpansion(ctx context.Context, chatManage *types.
 					SkipContextEnrichment: true, // Pipeline handles context assembly in merge stage
 				}
 				// Apply knowledge ID filter if this is a partial KB search
-				if t.Type == types.SearchTargetTypeKnowledge {
+				if len(t.KnowledgeIDs) > 0 {
 					paramsExp.KnowledgeIDs = t.KnowledgeIDs
 				}
 				res, err := p.knowledgeBaseService.HybridSearch(ctx, t.KnowledgeBaseID, paramsExp)
@@ -114,7 +114,7 @@ func (p *PluginQueryUnderstand) OnEvent(ctx context.Context,
 
 	// --- Call model ---
 	thinking := false
-	response, err := rewriteModel.Chat(ctx, []chat.Message{
+	response, err := rewriteModel.Chat(chat.WithResponseCache(ctx, "query_understand"), []chat.Message{
 		{Role: "system", Content: systemContent},
 		userMsg,
 	}, &chat.ChatOptions{
@@ -29,6 +29,32 @@ func NewPluginRerank(eventManager *EventManager, modelService interfaces.ModelSe
 	return res
 }
 
+// withFallbacks wraps the rerank model with the configured fallback models,
+// which are tried in order when it fails. Fallbacks that cannot be loaded
+// are skipped.
+func (p *PluginRerank) withFallbacks(ctx context.Context,
+	chatManage *types.ChatManage, primary rerank.Reranker,
+) rerank.Reranker {
+	var fallbacks []rerank.Reranker
+	seen := map[string]bool{chatManage.RerankModelID: true}
+	for _, modelID := range chatManage.RerankFallbackModelIDs {
+		if modelID == "" || seen[modelID] {
+			continue
+		}
+		seen[modelID] = true
+		model, err := p.modelService.GetRerankModel(ctx, modelID)
+		if err != nil {
+			pipelineWarn(ctx, "Rerank", "get_fallback_model", map[string]interface{}{
+				"model_id": modelID,
+				"error":    err.Error(),
+			})
+			continue
+		}
+		fallbacks = append(fallbacks, model)
+	}
+	return rerank.NewFallbackReranker(primary, fallbacks...)
+}
+
 // ActivationEvents returns the event types this plugin handles
 func (p *PluginRerank) ActivationEvents() []types.EventType {
 	return []types.EventType{types.CHUNK_RERANK}
@@ -70,6 +96,7 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 		})
 		return ErrGetRerankModel.WithError(err)
 	}
+	rerankModel = p.withFallbacks(ctx, chatManage, rerankModel)
 
 	// Prepare passages for reranking (excluding DirectLoad results)
 	var passages []string
@@ -129,6 +156,7 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 	var rerankResp []rerank.RankResult
 	var rawRerankResp []rerank.RankResult
 	thresholdDegraded := false
+	effectiveThreshold := chatManage.RerankThreshold
 
 	// Only call rerank model if there are candidates
 	if len(candidatesToRerank) > 0 {
@@ -168,6 +196,7 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 				"reason":        "no results above original threshold, retrying with lower threshold",
 			})
 			chatManage.RerankThreshold = degradedThreshold
+			effectiveThreshold = degradedThreshold
 			rerankResp, rerankErr = p.rerank(ctx, chatManage, rerankModel, chatManage.RewriteQuery, passages, candidatesToRerank)
 			// Restore original threshold
 			chatManage.RerankThreshold = originalThreshold
@@ -192,6 +221,7 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 	pipelineInfo(ctx, "Rerank", "model_response", map[string]interface{}{
 		"result_cnt": len(rerankResp),
 	})
+	traceBelowThreshold(ctx, candidatesToRerank, rerankResp, effectiveThreshold)
 
 	logRerankInputScoreSample(ctx, chatManage.SearchResult)
 
@@ -242,6 +272,7 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 	}
 	final := applyMMR(ctx, reranked, chatManage, min(len(reranked), max(1, chatManage.RerankTopK)), 0.7)
 	chatManage.RerankResult = final
+	traceNotSelected(ctx, reranked, final)
 
 	// Log composite top scores and MMR selection summary
 	topN := min(3, len(reranked))
@@ -287,6 +318,43 @@ func (p *PluginRerank) OnEvent(ctx context.Context,
 	return next()
 }
 
+// traceBelowThreshold records the candidates the rerank model scored under
+// the threshold, i.e. those missing from the filtered response.
+func traceBelowThreshold(ctx context.Context,
+	candidates []*types.SearchResult, kept []rerank.RankResult, threshold float64,
+) {
+	if types.RetrievalTraceFromContext(ctx) == nil {
+		return
+	}
+	keptIdx := make(map[int]struct{}, len(kept))
+	for _, rr := range kept {
+		keptIdx[rr.Index] = struct{}{}
+	}
+	detail := fmt.Sprintf("threshold=%.4f", threshold)
+	for i, sr := range candidates {
+		if _, ok := keptIdx[i]; !ok {
+			traceExclusion(ctx, "rerank", types.ChunkExclusionBelowThreshold, sr, detail)
+		}
+	}
+}
+
+// traceNotSelected records reranked candidates that MMR left out of the
+// top-K selection.
+func traceNotSelected(ctx context.Context, reranked, selected []*types.SearchResult) {
+	if types.RetrievalTraceFromContext(ctx) == nil {
+		return
+	}
+	selectedIDs := make(map[string]struct{}, len(selected))
+	for _, sr := range selected {
+		selectedIDs[sr.ID] = struct{}{}
+	}
+	for _, sr := range reranked {
+		if _, ok := selectedIDs[sr.ID]; !ok {
+			traceExclusion(ctx, "rerank", types.ChunkExclusionTopK, sr, "not selected by MMR")
+		}
+	}
+}
+
 func buildRerankSpanOutput(
 	candidates []*types.SearchResult,
 	passages []string,
@@ -660,7 +728,7 @@ func cleanPassageForRerank(text string) string {
 	return strings.TrimSpace(text)
 }
 
-// getEnrichedPassage 合并Content、ImageInfo和GeneratedQuestions的文本内容
+// getEnrichedPassage 合并Content、ImageInfo、GeneratedQuestions和表格摘要的文本内容
 func getEnrichedPassage(ctx context.Context, result *types.SearchResult) string {
 	combinedText := cleanPassageForRerank(result.Content)
 	var enrichments []string
@@ -694,8 +762,14 @@ func getEnrichedPassage(ctx context.Context, result *types.SearchResult) string
 			pipelineWarn(ctx, "Rerank", "chunk_metadata_parse", map[string]interface{}{
 				"error": err.Error(),
 			})
-		} else if questionStrings := docMeta.GetQuestionStrings(); len(questionStrings) > 0 {
-			enrichments = append(enrichments, strings.Join(questionStrings, "; "))
+		} else {
+			if questionStrings := docMeta.GetQuestionStrings(); len(questionStrings) > 0 {
+				enrichments = append(enrichments, strings.Join(questionStrings, "; "))
+			}
+			// 表格摘要帮助重排模型理解表格 Chunk 的内容
+			if docMeta.Table != nil && docMeta.Table.Summary != "" {
+				enrichments = append(enrichments, docMeta.Table.Summary)
+			}
 		}
 	}
 
@@ -86,6 +86,10 @@ func (p *PluginSearch) OnEvent(ctx context.Context,
 		"vector_threshold":  chatManage.VectorThreshold,
 		"keyword_threshold": chatManage.KeywordThreshold,
 	})
+	// Collect degradation decisions from every HybridSearch fan-out so the
+	// fallback stage can tell "nothing relevant" from "retrieval is down".
+	ctx, statusRecorder := types.WithRetrievalStatusRecorder(ctx)
+
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 	allResults := make([]*types.SearchResult, 0)
@@ -94,7 +98,12 @@ func (p *PluginSearch) OnEvent(ctx context.Context,
 	// Goroutine 1: Knowledge base search using SearchTargets
 	go func() {
 		defer wg.Done()
-		kbResults := p.searchByTargets(ctx, chatManage)
+		var kbResults []*types.SearchResult
+		if queries := retrievalQueries(chatManage); len(queries) > 1 {
+			kbResults = p.searchMultiQuery(ctx, chatManage, queries)
+		} else {
+			kbResults = p.searchByTargets(ctx, chatManage, chatManage.RewriteQuery)
+		}
 		if len(kbResults) > 0 {
 			mu.Lock()
 			allResults = append(allResults, kbResults...)
@@ -116,6 +125,13 @@ func (p *PluginSearch) OnEvent(ctx context.Context,
 	wg.Wait()
 
 	chatManage.SearchResult = allResults
+	if status := statusRecorder.Status(); status.Degraded {
+		chatManage.RetrievalStatus = status
+		pipelineWarn(ctx, "Search", "retrieval_degraded", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"mode":       string(status.Mode),
+		})
+	}
 
 	logSearchScoreSample(ctx, "result_score_before_normalize", chatManage.SearchResult)
 
@@ -162,7 +178,11 @@ func getSearchResultFromHistory(chatManage *types.ChatManage) []*types.SearchRes
 	return nil
 }
 
-func removeDuplicateResults(results []*types.SearchResult) []*types.SearchResult {
+// removeDuplicateResults drops repeated chunk IDs and chunks whose content
+// signature matches an earlier one. Signature duplicates are recorded in the
+// retrieval trace under stage; repeated IDs are not, since the chunk itself
+// is kept.
+func removeDuplicateResults(ctx context.Context, stage string, results []*types.SearchResult) []*types.SearchResult {
 	seen := make(map[string]bool)
 	contentSig := make(map[string]string) // sig -> first chunk ID
 	var uniqueResults []*types.SearchResult
@@ -171,13 +191,14 @@ func removeDuplicateResults(results []*types.SearchResult) []*types.SearchResult
 		// as duplicates, because different child chunks of the same parent carry
 		// different content segments that may all be relevant.
 		if seen[r.ID] {
-			logger.Debugf(context.Background(), "Dedup: chunk %s removed due to duplicate ID", r.ID)
+			logger.Debugf(ctx, "Dedup: chunk %s removed due to duplicate ID", r.ID)
 			continue
 		}
 		sig := buildContentSignature(r.Content)
 		if sig != "" {
 			if firstChunk, exists := contentSig[sig]; exists {
-				logger.Debugf(context.Background(), "Dedup: chunk %s removed due to content signature (dup of %s, sig prefix: %.50s...)", r.ID, firstChunk, sig)
+				logger.Debugf(ctx, "Dedup: chunk %s removed due to content signature (dup of %s, sig prefix: %.50s...)", r.ID, firstChunk, sig)
+				traceExclusion(ctx, stage, types.ChunkExclusionDeduplicated, r, "same content as "+firstChunk)
 				continue
 			}
 			contentSig[sig] = r.ID
@@ -272,6 +293,8 @@ func removePartialOverlaps(ctx context.Context, results []*types.SearchResult) [
 				"dropped_id": entries[victim].result.ID,
 				"contained":  contained,
 			})
+			traceExclusion(ctx, "merge", types.ChunkExclusionDeduplicated, entries[victim].result,
+				"overlaps "+entries[keptIdx].result.ID)
 		}
 	}
 
@@ -305,7 +328,7 @@ func logSearchScoreSample(ctx context.Context, action string, results []*types.S
 	}
 }
 
-// searchByTargets performs KB searches using pre-computed SearchTargets.
+// searchByTargets performs KB searches for query using pre-computed SearchTargets.
 // Targets sharing the same underlying embedding model (identified by model
 // name + endpoint, not just model ID) are grouped so the query embedding is
 // computed once per model AND all full-KB targets in a group are combined into
@@ -313,12 +336,13 @@ func logSearchScoreSample(ctx context.Context, action string, results []*types.S
 func (p *PluginSearch) searchByTargets(
 	ctx context.Context,
 	chatManage *types.ChatManage,
+	query string,
 ) []*types.SearchResult {
 	if len(chatManage.SearchTargets) == 0 {
 		return nil
 	}
 
-	queryText := strings.TrimSpace(chatManage.RewriteQuery)
+	queryText := strings.TrimSpace(query)
 
 	// Batch-fetch KB records to determine embedding model grouping.
 	// On failure, all targets fall into an empty-key group and HybridSearch
@@ -386,7 +410,7 @@ func (p *PluginSearch) searchByTargets(
 			var fullKBIDs []string
 			var knowledgeTargets []*types.SearchTarget
 			for _, t := range targets {
-				if t.Type == types.SearchTargetTypeKnowledgeBase && len(t.TagIDs) == 0 {
+				if t.IsWholeKnowledgeBase() {
 					fullKBIDs = append(fullKBIDs, t.KnowledgeBaseID)
 				} else {
 					knowledgeTargets = append(knowledgeTargets, t)
@@ -505,7 +529,7 @@ func (p *PluginSearch) searchSingleTarget(
 		TagIDs:                t.TagIDs,
 		SkipContextEnrichment: true,
 	}
-	if t.Type == types.SearchTargetTypeKnowledge {
+	if len(searchKnowledgeIDs) > 0 {
 		params.KnowledgeIDs = searchKnowledgeIDs
 	}
 	res, err := p.knowledgeBaseService.HybridSearch(ctx, t.KnowledgeBaseID, params)
@@ -576,41 +600,44 @@ func (p *PluginSearch) tryDirectChunkLoading(ctx context.Context, tenantID uint6
 	if len(uniqueKIDs) > 0 {
 		knowledges, err := p.knowledgeService.GetKnowledgeBatchWithSharedAccess(ctx, tenantID, uniqueKIDs)
 		if err != nil {
+			// Without the documents their ACLs cannot be checked; leave them
+			// to HybridSearch, which enforces the ACLs itself
 			logger.Warnf(ctx, "DirectLoad: Failed to fetch knowledge batch: %v", err)
-			// Continue without metadata
-		} else {
-			for _, k := range knowledges {
-				knowledgeMap[k.ID] = k
-			}
+			return nil, knowledgeIDs
+		}
+		for _, k := range knowledges {
+			knowledgeMap[k.ID] = k
 		}
 	}
 
+	// Direct loading bypasses the retrieval engines, so document ACLs are
+	// checked here
+	principal := types.RetrievalPrincipalFromContext(ctx)
 	var results []*types.SearchResult
 	for _, chunk := range allChunks {
-		res := &types.SearchResult{
-			ID:            chunk.ID,
-			Content:       chunk.Content,
-			Score:         1.0, // Maximum score for direct matches
-			KnowledgeID:   chunk.KnowledgeID,
-			ChunkIndex:    chunk.ChunkIndex,
-			MatchType:     types.MatchTypeDirectLoad,
-			ChunkType:     string(chunk.ChunkType),
-			ParentChunkID: chunk.ParentChunkID,
-			ImageInfo:     chunk.ImageInfo,
-			ChunkMetadata: chunk.Metadata,
-			StartAt:       chunk.StartAt,
-			EndAt:         chunk.EndAt,
-		}
-
-		if k, ok := knowledgeMap[chunk.KnowledgeID]; ok {
-			res.KnowledgeTitle = k.Title
-			res.KnowledgeFilename = k.FileName
-			res.KnowledgeSource = k.Source
-			res.KnowledgeChannel = k.Channel
-			res.Metadata = k.GetMetadata()
+		k, ok := knowledgeMap[chunk.KnowledgeID]
+		if !ok || !k.ReadableBy(principal) {
+			continue
 		}
-
-		results = append(results, res)
+		results = append(results, &types.SearchResult{
+			ID:                chunk.ID,
+			Content:           chunk.Content,
+			Score:             1.0, // Maximum score for direct matches
+			KnowledgeID:       chunk.KnowledgeID,
+			ChunkIndex:        chunk.ChunkIndex,
+			KnowledgeTitle:    k.Title,
+			KnowledgeFilename: k.FileName,
+			KnowledgeSource:   k.Source,
+			KnowledgeChannel:  k.Channel,
+			Metadata:          k.GetMetadata(),
+			MatchType:         types.MatchTypeDirectLoad,
+			ChunkType:         string(chunk.ChunkType),
+			ParentChunkID:     chunk.ParentChunkID,
+			ImageInfo:         chunk.ImageInfo,
+			ChunkMetadata:     chunk.Metadata,
+			StartAt:           chunk.StartAt,
+			EndAt:             chunk.EndAt,
+		})
 	}
 
 	searchutil.EnrichSearchResultsImageInfo(ctx, p.chunkService.GetRepository(), tenantID, results)
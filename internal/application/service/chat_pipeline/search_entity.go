@@ -170,7 +170,7 @@ func (p *PluginSearchEntity) OnEvent(ctx context.Context,
 	searchutil.EnrichSearchResultsImageInfo(ctx, p.chunkRepo, types.MustTenantIDFromContext(ctx), entityResults)
 	chatManage.SearchResult = append(chatManage.SearchResult, entityResults...)
 	// remove duplicate results
-	chatManage.SearchResult = removeDuplicateResults(chatManage.SearchResult)
+	chatManage.SearchResult = removeDuplicateResults(ctx, "search", chatManage.SearchResult)
 	if len(chatManage.SearchResult) == 0 {
 		logger.Infof(ctx, "No new search result, session_id: %s", chatManage.SessionID)
 		return ErrSearchNothing
@@ -9,7 +9,13 @@ import (
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 )
 
-// PluginSearchParallel implements parallel search functionality combining chunk search and entity search
+// communitySearchTopK is how many graph community reports join the retrieved
+// chunks; a few reports give global questions an overview without crowding
+// out the passages.
+const communitySearchTopK = 3
+
+// PluginSearchParallel implements parallel search functionality combining chunk search, entity search
+// and graph community report search
 type PluginSearchParallel struct {
 	// Chunk search dependencies
 	knowledgeBaseService interfaces.KnowledgeBaseService
@@ -24,6 +30,9 @@ type PluginSearchParallel struct {
 	chunkRepo     interfaces.ChunkRepository
 	knowledgeRepo interfaces.KnowledgeRepository
 
+	// Community search dependencies
+	graphCommunityService interfaces.GraphCommunityService
+
 	// Internal plugins
 	searchPlugin       *PluginSearch
 	searchEntityPlugin *PluginSearchEntity
@@ -44,6 +53,7 @@ func NewPluginSearchParallel(
 	graphRepository interfaces.RetrieveGraphRepository,
 	chunkRepository interfaces.ChunkRepository,
 	knowledgeRepository interfaces.KnowledgeRepository,
+	graphCommunityService interfaces.GraphCommunityService,
 ) *PluginSearchParallel {
 	// Create internal plugins without registering them
 	searchPlugin := &PluginSearch{
@@ -65,17 +75,18 @@ func NewPluginSearchParallel(
 	}
 
 	res := &PluginSearchParallel{
-		knowledgeBaseService: knowledgeBaseService,
-		knowledgeService:     knowledgeService,
-		config:               config,
-		webSearchService:     webSearchService,
-		tenantService:        tenantService,
-		sessionService:       sessionService,
-		graphRepo:            graphRepository,
-		chunkRepo:            chunkRepository,
-		knowledgeRepo:        knowledgeRepository,
-		searchPlugin:         searchPlugin,
-		searchEntityPlugin:   searchEntityPlugin,
+		knowledgeBaseService:  knowledgeBaseService,
+		knowledgeService:      knowledgeService,
+		config:                config,
+		webSearchService:      webSearchService,
+		tenantService:         tenantService,
+		sessionService:        sessionService,
+		graphRepo:             graphRepository,
+		chunkRepo:             chunkRepository,
+		knowledgeRepo:         knowledgeRepository,
+		graphCommunityService: graphCommunityService,
+		searchPlugin:          searchPlugin,
+		searchEntityPlugin:    searchEntityPlugin,
 	}
 	eventManager.Register(res)
 	return res
@@ -110,6 +121,7 @@ func (p *PluginSearchParallel) OnEvent(ctx context.Context,
 	chunkCM.SearchResult = nil
 	entityCM := chatManage.Clone()
 	entityCM.SearchResult = nil
+	var communityResults []*types.SearchResult
 
 	noop := func() *PluginError { return nil }
 
@@ -148,24 +160,45 @@ func (p *PluginSearchParallel) OnEvent(ctx context.Context,
 				return err
 			},
 		},
+		{
+			Name: "community_search",
+			Run: func() *PluginError {
+				if !types.GraphStoreEnabled() || len(chatManage.SearchTargets) == 0 {
+					return nil
+				}
+				results, err := p.graphCommunityService.SearchCommunities(
+					ctx, chatManage.SearchTargets, chatManage.RewriteQuery, communitySearchTopK)
+				pipelineInfo(ctx, "SearchParallel", "community_search_done", map[string]interface{}{
+					"result_count": len(results),
+					"has_error":    err != nil,
+				})
+				if err != nil {
+					return ErrSearch.WithError(err)
+				}
+				communityResults = results
+				return nil
+			},
+		},
 	}
 
 	errs := RunParallel(tasks...)
 
 	// Merge results from both searches
 	chatManage.SearchResult = append(chunkCM.SearchResult, entityCM.SearchResult...)
-	chatManage.SearchResult = removeDuplicateResults(chatManage.SearchResult)
+	chatManage.SearchResult = append(chatManage.SearchResult, communityResults...)
+	chatManage.SearchResult = removeDuplicateResults(ctx, "search", chatManage.SearchResult)
 
 	for name, err := range errs {
 		logger.Warnf(ctx, "[SearchParallel] %s error: %v", name, err.Err)
 	}
 
 	pipelineInfo(ctx, "SearchParallel", "complete", map[string]interface{}{
-		"session_id":     chatManage.SessionID,
-		"chunk_results":  len(chunkCM.SearchResult),
-		"entity_results": len(entityCM.SearchResult),
-		"total_results":  len(chatManage.SearchResult),
-		"error_count":    len(errs),
+		"session_id":        chatManage.SessionID,
+		"chunk_results":     len(chunkCM.SearchResult),
+		"entity_results":    len(entityCM.SearchResult),
+		"community_results": len(communityResults),
+		"total_results":     len(chatManage.SearchResult),
+		"error_count":       len(errs),
 	})
 
 	if len(chatManage.SearchResult) == 0 {
@@ -0,0 +1,322 @@
+package chatpipeline
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const text2SQLRoutePrompt = `You decide whether a user's question is an analytical question that a SQL
+query over one of the databases below can answer: counting, summing,
+averaging, ranking, comparing or filtering records. Questions about concepts,
+procedures or document content are not analytical.
+
+Databases:
+%s
+Question: %s
+
+Answer with the number of the database that can answer the question, or 0
+if the question is not analytical or no database fits. Output only the number.`
+
+const text2SQLGeneratePrompt = `You write %s SQL that answers a user's question from the database below.
+
+Tables (name(column type, ...)):
+%s
+
+Question: %s
+
+Rules:
+- Write exactly one read-only SELECT statement; never modify data.
+- Use only the tables and columns listed above.
+- Do not use subqueries or WITH clauses.
+- Return at most %d rows; use LIMIT n OFFSET m, not LIMIT m, n.
+- Name computed columns with readable aliases.
+Output only the SQL, without explanation or code fences.`
+
+const text2SQLRepairPrompt = `
+
+Your previous query was:
+%s
+It failed with: %s
+Write a corrected query.`
+
+// text2SQLDescriptionRunes caps the connection description shown to the
+// router.
+const text2SQLDescriptionRunes = 300
+
+var (
+	text2SQLFence  = regexp.MustCompile("(?s)```(?:sql)?\\s*(.*?)```")
+	text2SQLNumber = regexp.MustCompile(`\d+`)
+)
+
+// PluginText2SQL answers analytical questions from the agent's SQL
+// connections. The chat model first routes the question to one connection
+// by its description, or to none when the question is not analytical; then
+// it writes a SELECT from the connection's schema, which is validated and
+// run read-only within the connection's limits, with one repair attempt on
+// failure. The result table and the SQL replace retrieval as the context of
+// the answer. Any failure leaves the question to the retrieval stages.
+type PluginText2SQL struct {
+	modelService interfaces.ModelService
+	sqlService   interfaces.SQLConnectionService
+}
+
+// NewPluginText2SQL creates a new Text2SQL plugin and registers it with the
+// event manager.
+func NewPluginText2SQL(eventManager *EventManager,
+	modelService interfaces.ModelService, sqlService interfaces.SQLConnectionService,
+) *PluginText2SQL {
+	res := &PluginText2SQL{modelService: modelService, sqlService: sqlService}
+	eventManager.Register(res)
+	return res
+}
+
+// ActivationEvents returns the list of event types this plugin responds to.
+func (p *PluginText2SQL) ActivationEvents() []types.EventType {
+	return []types.EventType{types.TEXT2SQL}
+}
+
+// OnEvent sets MergeResult to the query result and Intent to
+// IntentDataQuery when the question was answered from a connection.
+func (p *PluginText2SQL) OnEvent(ctx context.Context,
+	eventType types.EventType, chatManage *types.ChatManage, next func() *PluginError,
+) *PluginError {
+	if len(chatManage.SQLConnectionIDs) == 0 || !chatManage.NeedsRetrieval() {
+		return next()
+	}
+	conns := p.loadConnections(ctx, chatManage)
+	if len(conns) == 0 {
+		return next()
+	}
+	query := chatManage.RewriteQuery
+	if query == "" {
+		query = chatManage.Query
+	}
+
+	spanCtx, span := langfuse.GetManager().StartSpan(ctx, langfuse.SpanOptions{
+		Name: "text2sql",
+		Input: map[string]interface{}{
+			"query":          query,
+			"connection_cnt": len(conns),
+		},
+	})
+	conn := p.route(spanCtx, chatManage, query, conns)
+	if conn == nil {
+		span.Finish(map[string]interface{}{"routed": false}, nil, nil)
+		pipelineInfo(ctx, "Text2SQL", "not_analytical", map[string]interface{}{
+			"session_id":     chatManage.SessionID,
+			"connection_cnt": len(conns),
+		})
+		return next()
+	}
+
+	result, err := p.answer(spanCtx, chatManage, query, conn)
+	if err != nil {
+		span.Finish(map[string]interface{}{
+			"connection_id": conn.ID,
+			"sql":           sqlOf(result),
+		}, nil, err)
+		pipelineWarn(ctx, "Text2SQL", "query_failed", map[string]interface{}{
+			"session_id":    chatManage.SessionID,
+			"connection_id": conn.ID,
+			"error":         err.Error(),
+		})
+		return next()
+	}
+
+	chatManage.MergeResult = []*types.SearchResult{text2SQLSearchResult(conn, result)}
+	chatManage.Intent = types.IntentDataQuery
+	span.Finish(map[string]interface{}{
+		"connection_id": conn.ID,
+		"sql":           result.SQL,
+		"row_cnt":       len(result.Rows),
+		"truncated":     result.Truncated,
+	}, nil, nil)
+	pipelineInfo(ctx, "Text2SQL", "output", map[string]interface{}{
+		"session_id":    chatManage.SessionID,
+		"connection_id": conn.ID,
+		"row_cnt":       len(result.Rows),
+		"truncated":     result.Truncated,
+	})
+	return next()
+}
+
+// loadConnections returns the agent's connections that still exist.
+func (p *PluginText2SQL) loadConnections(ctx context.Context, chatManage *types.ChatManage) []*types.SQLConnection {
+	conns := make([]*types.SQLConnection, 0, len(chatManage.SQLConnectionIDs))
+	for _, id := range chatManage.SQLConnectionIDs {
+		conn, err := p.sqlService.GetConnection(ctx, chatManage.TenantID, id)
+		if err != nil || conn == nil {
+			pipelineWarn(ctx, "Text2SQL", "load_connection", map[string]interface{}{
+				"session_id":    chatManage.SessionID,
+				"connection_id": id,
+				"error":         fmt.Sprint(err),
+			})
+			continue
+		}
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+// route asks the chat model which connection, if any, answers the question.
+func (p *PluginText2SQL) route(ctx context.Context, chatManage *types.ChatManage,
+	query string, conns []*types.SQLConnection,
+) *types.SQLConnection {
+	modelID := chatManage.ChatModelID
+	if chatManage.QueryUnderstandModelID != "" {
+		modelID = chatManage.QueryUnderstandModelID
+	}
+	model, err := p.modelService.GetChatModel(ctx, modelID)
+	if err != nil {
+		pipelineWarn(ctx, "Text2SQL", "get_model", map[string]interface{}{
+			"session_id":    chatManage.SessionID,
+			"chat_model_id": modelID,
+			"error":         err.Error(),
+		})
+		return nil
+	}
+
+	var list strings.Builder
+	for i, c := range conns {
+		fmt.Fprintf(&list, "[%d] %s", i+1, c.Name)
+		if desc := text2SQLDescription(c); desc != "" {
+			fmt.Fprintf(&list, ": %s", desc)
+		}
+		list.WriteString("\n")
+	}
+	thinking := false
+	response, err := model.Chat(ctx, []chat.Message{
+		{Role: "user", Content: fmt.Sprintf(text2SQLRoutePrompt, list.String(), query)},
+	}, &chat.ChatOptions{
+		Temperature:         0.1,
+		MaxCompletionTokens: 10,
+		Thinking:            &thinking,
+	})
+	if err != nil {
+		pipelineWarn(ctx, "Text2SQL", "route_call", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return nil
+	}
+	n := parseText2SQLRoute(response.Content)
+	if n < 1 || n > len(conns) {
+		return nil
+	}
+	return conns[n-1]
+}
+
+// answer generates and runs the SQL for the question, retrying once with
+// the error of the first attempt. On failure the returned result, if any,
+// holds the last SQL tried.
+func (p *PluginText2SQL) answer(ctx context.Context, chatManage *types.ChatManage,
+	query string, conn *types.SQLConnection,
+) (*types.SQLQueryResult, error) {
+	schema, err := p.sqlService.DescribeSchema(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if schema == "" {
+		return nil, fmt.Errorf("no tables to query")
+	}
+	model, err := p.modelService.GetChatModel(ctx, chatManage.ChatModelID)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := fmt.Sprintf(text2SQLGeneratePrompt, text2SQLDialect(conn.Driver), schema, query, conn.EffectiveMaxRows())
+	var sqlText string
+	for attempt := 0; attempt < 2; attempt++ {
+		content := prompt
+		if attempt > 0 {
+			content += fmt.Sprintf(text2SQLRepairPrompt, sqlText, err)
+		}
+		thinking := false
+		response, callErr := model.Chat(ctx, []chat.Message{
+			{Role: "user", Content: content},
+		}, &chat.ChatOptions{
+			Temperature: 0.1,
+			Thinking:    &thinking,
+		})
+		if callErr != nil {
+			return &types.SQLQueryResult{SQL: sqlText}, callErr
+		}
+		sqlText = extractText2SQL(response.Content)
+		var result *types.SQLQueryResult
+		if result, err = p.sqlService.ExecuteQuery(ctx, conn, sqlText); err == nil {
+			return result, nil
+		}
+	}
+	return &types.SQLQueryResult{SQL: sqlText}, err
+}
+
+// text2SQLSearchResult turns a query result into the context of the answer,
+// with the SQL alongside the table so the answer can show how it was
+// computed.
+func text2SQLSearchResult(conn *types.SQLConnection, result *types.SQLQueryResult) *types.SearchResult {
+	content := fmt.Sprintf("Result of a SQL query on database %q.\n\nSQL:\n```sql\n%s\n```\n\nResult:\n%s",
+		conn.Name, result.SQL, result.Markdown())
+	return &types.SearchResult{
+		ID:             "text2sql_" + conn.ID,
+		Content:        content,
+		Score:          1.0,
+		MatchType:      types.MatchTypeText2SQL,
+		KnowledgeTitle: conn.Name,
+		Metadata: map[string]string{
+			"sql_connection_id": conn.ID,
+			"sql":               result.SQL,
+			"row_count":         strconv.Itoa(len(result.Rows)),
+			"truncated":         strconv.FormatBool(result.Truncated),
+		},
+	}
+}
+
+func text2SQLDialect(driver types.SQLDriver) string {
+	if driver == types.SQLDriverMySQL {
+		return "MySQL"
+	}
+	return "PostgreSQL"
+}
+
+func text2SQLDescription(conn *types.SQLConnection) string {
+	desc := strings.Join(strings.Fields(conn.Description), " ")
+	if runes := []rune(desc); len(runes) > text2SQLDescriptionRunes {
+		desc = string(runes[:text2SQLDescriptionRunes]) + "..."
+	}
+	return desc
+}
+
+// parseText2SQLRoute reads the routed database number, 0 when there is none.
+func parseText2SQLRoute(content string) int {
+	content = regThinkTags.ReplaceAllString(content, "")
+	n, err := strconv.Atoi(text2SQLNumber.FindString(content))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// extractText2SQL strips thinking, code fences and a trailing semicolon from
+// the generated SQL.
+func extractText2SQL(content string) string {
+	content = regThinkTags.ReplaceAllString(content, "")
+	if m := text2SQLFence.FindStringSubmatch(content); m != nil {
+		content = m[1]
+	}
+	return strings.TrimSuffix(strings.TrimSpace(content), ";")
+}
+
+func sqlOf(result *types.SQLQueryResult) string {
+	if result == nil {
+		return ""
+	}
+	return result.SQL
+}
@@ -0,0 +1,42 @@
+package chatpipeline
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseText2SQLRoute(t *testing.T) {
+	assert.Equal(t, 2, parseText2SQLRoute("2"))
+	assert.Equal(t, 1, parseText2SQLRoute("<think>maybe 3?</think>Database 1"))
+	assert.Equal(t, 0, parseText2SQLRoute("0"))
+	assert.Equal(t, 0, parseText2SQLRoute("none"))
+}
+
+func TestExtractText2SQL(t *testing.T) {
+	assert.Equal(t, "SELECT 1", extractText2SQL("SELECT 1;"))
+	assert.Equal(t, "SELECT region, SUM(amount) FROM orders GROUP BY region",
+		extractText2SQL("Here it is:\n```sql\nSELECT region, SUM(amount) FROM orders GROUP BY region;\n```"))
+	assert.Equal(t, "SELECT 2", extractText2SQL("<think>count?</think>```\nSELECT 2\n```"))
+}
+
+func TestText2SQLSearchResult(t *testing.T) {
+	conn := &types.SQLConnection{ID: "c1", Name: "sales"}
+	result := &types.SQLQueryResult{
+		SQL:       "SELECT COUNT(*) AS orders FROM orders",
+		Columns:   []string{"orders"},
+		Rows:      [][]string{{"42"}},
+		Truncated: false,
+	}
+
+	sr := text2SQLSearchResult(conn, result)
+
+	assert.Equal(t, "text2sql_c1", sr.ID)
+	assert.Equal(t, types.MatchTypeText2SQL, sr.MatchType)
+	assert.Equal(t, "sales", sr.KnowledgeTitle)
+	assert.Contains(t, sr.Content, "```sql\nSELECT COUNT(*) AS orders FROM orders\n```")
+	assert.Contains(t, sr.Content, "| orders |\n| --- |\n| 42 |")
+	assert.Equal(t, "SELECT COUNT(*) AS orders FROM orders", sr.Metadata["sql"])
+	assert.Equal(t, "1", sr.Metadata["row_count"])
+}
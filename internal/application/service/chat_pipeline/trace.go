@@ -0,0 +1,164 @@
+package chatpipeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/google/uuid"
+)
+
+const (
+	// traceResultLimit caps the results listed per stage in a debug trace.
+	traceResultLimit = 50
+	// traceContentRunes caps the chunk content shown per result.
+	traceContentRunes = 200
+)
+
+// NewPipelineTrace starts the debug trace of a run from its request
+// settings.
+func NewPipelineTrace(chatManage *types.ChatManage) *types.PipelineTrace {
+	return &types.PipelineTrace{
+		Query:            chatManage.Query,
+		Config:           types.EffectivePipelineConfig(&chatManage.PipelineRequest),
+		KnowledgeBaseIDs: chatManage.KnowledgeBaseIDs,
+		KnowledgeIDs:     chatManage.KnowledgeIDs,
+		ChatModelID:      chatManage.ChatModelID,
+		RerankModelID:    chatManage.RerankModelID,
+		Stages:           []types.PipelineStageTrace{},
+	}
+}
+
+// TraceStage records the outcome of a stage in the run's debug trace, if
+// there is one.
+func TraceStage(chatManage *types.ChatManage, eventType types.EventType,
+	duration time.Duration, err *PluginError,
+) {
+	if chatManage.Trace == nil {
+		return
+	}
+	stage := types.PipelineStageTrace{
+		Stage:      eventType,
+		DurationMs: duration.Milliseconds(),
+		Output:     stageTraceOutput(eventType, chatManage),
+	}
+	if err != nil {
+		stage.Error = err.ErrorType
+	}
+	chatManage.Trace.AddStage(stage)
+}
+
+// stageTraceOutput snapshots the state a stage produces.
+func stageTraceOutput(eventType types.EventType, chatManage *types.ChatManage) map[string]interface{} {
+	switch eventType {
+	case types.LOAD_HISTORY:
+		return map[string]interface{}{"history_rounds": len(chatManage.History)}
+	case types.QUERY_UNDERSTAND:
+		return map[string]interface{}{
+			"rewrite_query": chatManage.RewriteQuery,
+			"intent":        chatManage.Intent,
+		}
+	case types.MEMORY_QUERY_REWRITE:
+		return map[string]interface{}{"rewrite_query": chatManage.RewriteQuery}
+	case types.GUARDRAILS_INPUT, types.GUARDRAILS_CONTEXT:
+		return map[string]interface{}{"detections": chatManage.GuardrailDetections}
+	case types.KB_ROUTE:
+		return map[string]interface{}{"knowledge_base_ids": chatManage.KnowledgeBaseIDs}
+	case types.QUERY_VARIANTS:
+		return map[string]interface{}{"query_variants": chatManage.QueryVariants}
+	case types.TEXT2SQL:
+		return map[string]interface{}{"intent": chatManage.Intent, "results": traceResults(chatManage.MergeResult)}
+	case types.CHUNK_SEARCH, types.CHUNK_SEARCH_PARALLEL, types.ENTITY_SEARCH:
+		return map[string]interface{}{
+			"result_count": len(chatManage.SearchResult),
+			"results":      traceResults(chatManage.SearchResult),
+		}
+	case types.CHUNK_RERANK, types.WEB_FETCH:
+		return map[string]interface{}{
+			"result_count": len(chatManage.RerankResult),
+			"results":      traceResults(chatManage.RerankResult),
+		}
+	case types.CHUNK_MERGE, types.FILTER_TOP_K, types.CHUNK_CONTEXT_WINDOW, types.DATA_ANALYSIS:
+		return map[string]interface{}{
+			"result_count": len(chatManage.MergeResult),
+			"results":      traceResults(chatManage.MergeResult),
+		}
+	case types.MEMORY_RETRIEVAL:
+		return map[string]interface{}{
+			"profile":         chatManage.MemoryProfile != "",
+			"session_summary": chatManage.MemorySessionSummary != "",
+			"episode_count":   len(chatManage.MemoryEpisodes),
+		}
+	case types.CONTEXT_PACK:
+		return map[string]interface{}{
+			"result_count":   len(chatManage.MergeResult),
+			"history_rounds": len(chatManage.History),
+			"episode_count":  len(chatManage.MemoryEpisodes),
+		}
+	case types.INTO_CHAT_MESSAGE:
+		return map[string]interface{}{"citation_source_count": len(chatManage.CitationSources)}
+	default:
+		return nil
+	}
+}
+
+func traceResults(results []*types.SearchResult) []types.PipelineTraceResult {
+	out := make([]types.PipelineTraceResult, 0, min(len(results), traceResultLimit))
+	for _, r := range results {
+		if len(out) == traceResultLimit {
+			break
+		}
+		if r == nil {
+			continue
+		}
+		content := r.Content
+		if runes := []rune(content); len(runes) > traceContentRunes {
+			content = string(runes[:traceContentRunes]) + "..."
+		}
+		out = append(out, types.PipelineTraceResult{
+			ChunkID:        r.ID,
+			KnowledgeID:    r.KnowledgeID,
+			KnowledgeTitle: r.KnowledgeTitle,
+			Score:          r.Score,
+			MatchType:      r.MatchType,
+			Content:        content,
+		})
+	}
+	return out
+}
+
+// EmitPipelineTrace completes the run's debug trace with the dropped
+// candidates and the final prompt, and streams it. It must run before the
+// answer completes the message. The trace is detached from chatManage, so
+// stages run afterwards are not traced and later calls are no-ops.
+func EmitPipelineTrace(ctx context.Context, chatManage *types.ChatManage) {
+	trace := chatManage.Trace
+	if trace == nil || chatManage.EventBus == nil {
+		return
+	}
+	chatManage.Trace = nil
+
+	trace.Exclusions = types.RetrievalTraceFromContext(ctx).Exclusions()
+	if trace.Exclusions == nil {
+		trace.Exclusions = []types.ChunkExclusion{}
+	}
+	if chatManage.UserContent != "" {
+		trace.Prompt = nil
+		for _, m := range prepareMessagesWithHistory(chatManage) {
+			trace.Prompt = append(trace.Prompt, types.PipelineTraceMessage{Role: m.Role, Content: m.Content})
+		}
+	}
+	if err := chatManage.EventBus.Emit(ctx, types.Event{
+		ID:        fmt.Sprintf("%s-debug-trace", uuid.New().String()[:8]),
+		Type:      types.EventType(event.EventAgentDebugTrace),
+		SessionID: chatManage.SessionID,
+		Data:      event.AgentDebugTraceData{Trace: trace},
+	}); err != nil {
+		pipelineWarn(ctx, "Trace", "emit_error", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+	}
+}
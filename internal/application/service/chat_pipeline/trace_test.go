@@ -0,0 +1,64 @@
+package chatpipeline
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceStage(t *testing.T) {
+	cm := &types.ChatManage{}
+	cm.Query = "How much leave?"
+	cm.RerankResult = []*types.SearchResult{{ID: "c1", KnowledgeID: "k1", Score: 0.9, Content: strings.Repeat("长", 300)}, nil}
+
+	// Without a trace nothing is recorded
+	TraceStage(cm, types.CHUNK_RERANK, time.Second, nil)
+
+	cm.Trace = NewPipelineTrace(cm)
+	cm.RewriteQuery = "annual leave days"
+	TraceStage(cm, types.QUERY_UNDERSTAND, 20*time.Millisecond, nil)
+	TraceStage(cm, types.CHUNK_RERANK, time.Second, ErrSearchNothing)
+
+	require.Len(t, cm.Trace.Stages, 2)
+	assert.Equal(t, "How much leave?", cm.Trace.Query)
+	assert.Equal(t, int64(20), cm.Trace.Stages[0].DurationMs)
+	assert.Equal(t, "annual leave days", cm.Trace.Stages[0].Output["rewrite_query"])
+
+	rerank := cm.Trace.Stages[1]
+	assert.Equal(t, ErrSearchNothing.ErrorType, rerank.Error)
+	results := rerank.Output["results"].([]types.PipelineTraceResult)
+	require.Len(t, results, 1)
+	assert.Equal(t, "c1", results[0].ChunkID)
+	assert.Equal(t, traceContentRunes+len("..."), len([]rune(results[0].Content)))
+}
+
+func TestEmitPipelineTrace(t *testing.T) {
+	bus := &recordingEventBus{}
+	cm := &types.ChatManage{
+		PipelineRequest: types.PipelineRequest{SessionID: "sess-1", Query: "q"},
+		PipelineContext: types.PipelineContext{EventBus: bus},
+	}
+	cm.Trace = NewPipelineTrace(cm)
+	TraceStage(cm, types.LOAD_HISTORY, time.Millisecond, nil)
+
+	ctx, retrieval := types.WithRetrievalTrace(context.Background())
+	retrieval.Exclude(types.ChunkExclusion{ChunkID: "c9", Stage: "rerank", Reason: types.ChunkExclusionBelowThreshold})
+
+	EmitPipelineTrace(ctx, cm)
+	EmitPipelineTrace(ctx, cm)
+
+	require.Len(t, bus.events, 1)
+	assert.Equal(t, types.EventType(event.EventAgentDebugTrace), bus.events[0].Type)
+	data := bus.events[0].Data.(event.AgentDebugTraceData)
+	require.Len(t, data.Trace.Stages, 1)
+	require.Len(t, data.Trace.Exclusions, 1)
+	assert.Equal(t, "c9", data.Trace.Exclusions[0].ChunkID)
+	assert.Empty(t, data.Trace.Prompt)
+	assert.Nil(t, cm.Trace)
+}
@@ -0,0 +1,196 @@
+package chatpipeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/google/uuid"
+)
+
+const verificationJudgePrompt = `You check an answer against the retrieved passages it was written from.
+
+Passages:
+%s
+
+Question: %s
+
+Answer:
+%s
+
+Split the answer into its factual claims (at most %d, the most important ones) and judge each one: a claim is supported only if the passages state or directly imply it. Greetings, hedges and statements that no information was found are not claims.
+
+Output only JSON in this form:
+{"claims": [{"claim": "<claim, quoted or closely paraphrased>", "supported": true, "reason": "<one short sentence>"}]}`
+
+const verificationRegeneratePrompt = `These statements of your answer are not supported by the retrieved passages:
+%s
+
+Rewrite the answer using only information from the passages: drop the unsupported statements, or say that the passages do not cover them. Keep the supported content and the language of the answer. Output only the rewritten answer.`
+
+// verificationMaxClaims caps the claims the judge lists per answer.
+const verificationMaxClaims = 10
+
+// answerVerificationEnabled reports whether the answer is checked against
+// the retrieved context.
+func answerVerificationEnabled(chatManage *types.ChatManage) bool {
+	return chatManage.AnswerVerificationEnabled && len(chatManage.CitationSources) > 0
+}
+
+// verifyAnswer checks the claims of answer against the retrieved context
+// and handles unsupported ones with the configured action. It returns the
+// answer to deliver, which is answer itself, answer with a disclaimer
+// appended, or a regenerated answer; the verification is nil when the judge
+// could not be run, in which case answer is returned unchanged.
+func verifyAnswer(ctx context.Context, chatModel chat.Chat, chatManage *types.ChatManage,
+	chatMessages []chat.Message, opt *chat.ChatOptions, answer string,
+) (string, *types.AnswerVerification) {
+	verification, err := judgeAnswer(ctx, chatModel, chatManage, answer)
+	if err != nil {
+		pipelineWarn(ctx, "Verification", "judge_error", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+		return answer, nil
+	}
+
+	unsupported := verification.UnsupportedClaims()
+	if len(unsupported) > 0 && chatManage.AnswerVerificationAction == types.AnswerVerificationRegenerate {
+		if revised, err := regenerateAnswer(ctx, chatModel, chatMessages, opt, answer, unsupported); err != nil {
+			pipelineWarn(ctx, "Verification", "regenerate_error", map[string]interface{}{
+				"session_id": chatManage.SessionID,
+				"error":      err.Error(),
+			})
+		} else if revisedVerification, err := judgeAnswer(ctx, chatModel, chatManage, revised); err != nil {
+			pipelineWarn(ctx, "Verification", "judge_error", map[string]interface{}{
+				"session_id": chatManage.SessionID,
+				"error":      err.Error(),
+			})
+		} else {
+			answer, verification = revised, revisedVerification
+			verification.Regenerated = true
+			unsupported = verification.UnsupportedClaims()
+		}
+	}
+	if len(unsupported) > 0 {
+		verification.Disclaimer = verificationDisclaimer(chatManage.Language, unsupported)
+		answer += verification.Disclaimer
+	}
+
+	pipelineInfo(ctx, "Verification", "output", map[string]interface{}{
+		"session_id":      chatManage.SessionID,
+		"verdict":         verification.Verdict,
+		"claim_cnt":       len(verification.Claims),
+		"unsupported_cnt": len(unsupported),
+		"regenerated":     verification.Regenerated,
+	})
+	return answer, verification
+}
+
+// judgeAnswer asks the chat model to judge each claim of answer.
+func judgeAnswer(ctx context.Context, chatModel chat.Chat, chatManage *types.ChatManage,
+	answer string,
+) (*types.AnswerVerification, error) {
+	answer = strings.TrimSpace(regThinkTags.ReplaceAllString(answer, ""))
+	if answer == "" {
+		return types.NewAnswerVerification(nil), nil
+	}
+	thinking := false
+	response, err := chatModel.Chat(ctx, []chat.Message{{
+		Role: "user",
+		Content: fmt.Sprintf(verificationJudgePrompt,
+			chatManage.RenderedContexts, chatManage.Query, answer, verificationMaxClaims),
+	}}, &chat.ChatOptions{
+		Temperature: 0.1,
+		Thinking:    &thinking,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseVerificationJudgement(response.Content)
+}
+
+// parseVerificationJudgement reads the judge's JSON output.
+func parseVerificationJudgement(content string) (*types.AnswerVerification, error) {
+	var out struct {
+		Claims []types.ClaimVerdict `json:"claims"`
+	}
+	content = strings.TrimSpace(regThinkTags.ReplaceAllString(content, ""))
+	if err := common.ParseLLMJsonResponse(content, &out); err != nil {
+		return nil, fmt.Errorf("parse judgement: %w", err)
+	}
+	claims := make([]types.ClaimVerdict, 0, len(out.Claims))
+	for _, c := range out.Claims {
+		if c.Claim = strings.TrimSpace(c.Claim); c.Claim != "" {
+			claims = append(claims, c)
+		}
+	}
+	if len(claims) > verificationMaxClaims {
+		claims = claims[:verificationMaxClaims]
+	}
+	return types.NewAnswerVerification(claims), nil
+}
+
+// regenerateAnswer asks the chat model to rewrite answer without the
+// unsupported claims, continuing the conversation the answer came from.
+func regenerateAnswer(ctx context.Context, chatModel chat.Chat, chatMessages []chat.Message,
+	opt *chat.ChatOptions, answer string, unsupported []string,
+) (string, error) {
+	messages := append(append([]chat.Message(nil), chatMessages...),
+		chat.Message{Role: "assistant", Content: answer},
+		chat.Message{Role: "user", Content: fmt.Sprintf(verificationRegeneratePrompt, verificationBullets(unsupported))},
+	)
+	response, err := chatModel.Chat(ctx, messages, opt)
+	if err != nil {
+		return "", err
+	}
+	revised := strings.TrimSpace(regThinkTags.ReplaceAllString(response.Content, ""))
+	if revised == "" {
+		return "", fmt.Errorf("empty regenerated answer")
+	}
+	return revised, nil
+}
+
+// verificationDisclaimer returns the note appended to an answer with
+// unsupported claims.
+func verificationDisclaimer(lang string, unsupported []string) string {
+	if types.LanguageLocaleName(lang) == "Chinese (Simplified)" || lang == "" {
+		return "\n\n---\n⚠️ 以下内容未能在参考资料中找到依据，请谨慎参考：\n" + verificationBullets(unsupported)
+	}
+	return "\n\n---\n⚠️ The following statements could not be verified against the referenced sources; treat them with caution:\n" +
+		verificationBullets(unsupported)
+}
+
+func verificationBullets(items []string) string {
+	var b strings.Builder
+	for i, item := range items {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString("- " + strings.Join(strings.Fields(item), " "))
+	}
+	return b.String()
+}
+
+// emitVerification streams the verification of the answer. It must run
+// before the final answer chunk, which completes the message.
+func emitVerification(ctx context.Context, chatManage *types.ChatManage, verification *types.AnswerVerification) {
+	if verification == nil || chatManage.EventBus == nil {
+		return
+	}
+	if err := chatManage.EventBus.Emit(ctx, types.Event{
+		ID:        fmt.Sprintf("%s-verification", uuid.New().String()[:8]),
+		Type:      types.EventType(event.EventAgentVerification),
+		SessionID: chatManage.SessionID,
+		Data:      event.AgentVerificationData{Verification: verification},
+	}); err != nil {
+		pipelineWarn(ctx, "Verification", "emit_error", map[string]interface{}{
+			"session_id": chatManage.SessionID,
+			"error":      err.Error(),
+		})
+	}
+}
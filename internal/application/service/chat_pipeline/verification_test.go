@@ -0,0 +1,102 @@
+package chatpipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedChat answers non-streamed calls with the given replies in order.
+type scriptedChat struct {
+	replies []string
+	calls   [][]chat.Message
+}
+
+func (c *scriptedChat) Chat(_ context.Context, messages []chat.Message, _ *chat.ChatOptions) (*types.ChatResponse, error) {
+	c.calls = append(c.calls, messages)
+	if len(c.calls) > len(c.replies) {
+		return nil, errors.New("no more replies")
+	}
+	return &types.ChatResponse{Content: c.replies[len(c.calls)-1]}, nil
+}
+
+func (c *scriptedChat) ChatStream(context.Context, []chat.Message, *chat.ChatOptions) (<-chan types.StreamResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *scriptedChat) GetModelName() string { return "scripted" }
+func (c *scriptedChat) GetModelID() string   { return "scripted" }
+
+func TestParseVerificationJudgement(t *testing.T) {
+	v, err := parseVerificationJudgement("<think>hmm</think>```json\n" +
+		`{"claims": [{"claim": " Leave is 10 days ", "supported": true}, {"claim": "", "supported": false}, {"claim": "It accrues weekly", "supported": false, "reason": "passage 1 says monthly"}]}` +
+		"\n```")
+	require.NoError(t, err)
+	assert.Equal(t, types.AnswerVerdictPartiallySupported, v.Verdict)
+	assert.Equal(t, []types.ClaimVerdict{
+		{Claim: "Leave is 10 days", Supported: true},
+		{Claim: "It accrues weekly", Reason: "passage 1 says monthly"},
+	}, v.Claims)
+
+	v, err = parseVerificationJudgement(`{"claims": []}`)
+	require.NoError(t, err)
+	assert.Equal(t, types.AnswerVerdictSupported, v.Verdict)
+	assert.NotNil(t, v.Claims)
+
+	_, err = parseVerificationJudgement("all claims are supported")
+	assert.Error(t, err)
+}
+
+func TestVerifyAnswer_disclaimer(t *testing.T) {
+	model := &scriptedChat{replies: []string{
+		`{"claims": [{"claim": "Leave is 10 days", "supported": true}, {"claim": "It accrues weekly", "supported": false}]}`,
+	}}
+	chatManage := &types.ChatManage{}
+	chatManage.Language = "en-US"
+
+	answer, v := verifyAnswer(context.Background(), model, chatManage, nil, &chat.ChatOptions{},
+		"Leave is 10 days and accrues weekly.")
+	require.NotNil(t, v)
+	assert.False(t, v.Regenerated)
+	assert.NotEmpty(t, v.Disclaimer)
+	assert.Equal(t, "Leave is 10 days and accrues weekly."+v.Disclaimer, answer)
+	assert.Contains(t, v.Disclaimer, "- It accrues weekly")
+	assert.Len(t, model.calls, 1)
+}
+
+func TestVerifyAnswer_regenerate(t *testing.T) {
+	model := &scriptedChat{replies: []string{
+		`{"claims": [{"claim": "It accrues weekly", "supported": false}]}`,
+		"Leave is 10 days.",
+		`{"claims": [{"claim": "Leave is 10 days", "supported": true}]}`,
+	}}
+	chatManage := &types.ChatManage{}
+	chatManage.AnswerVerificationAction = types.AnswerVerificationRegenerate
+	prompt := []chat.Message{{Role: "system", Content: "sys"}, {Role: "user", Content: "How much leave?"}}
+
+	answer, v := verifyAnswer(context.Background(), model, chatManage, prompt, &chat.ChatOptions{},
+		"Leave accrues weekly.")
+	require.NotNil(t, v)
+	assert.Equal(t, "Leave is 10 days.", answer)
+	assert.True(t, v.Regenerated)
+	assert.Empty(t, v.Disclaimer)
+	assert.Equal(t, types.AnswerVerdictSupported, v.Verdict)
+
+	// The regeneration continues the original conversation.
+	regen := model.calls[1]
+	require.Len(t, regen, 4)
+	assert.Equal(t, "assistant", regen[2].Role)
+	assert.Contains(t, regen[3].Content, "- It accrues weekly")
+}
+
+func TestVerifyAnswer_judgeFailureKeepsAnswer(t *testing.T) {
+	model := &scriptedChat{replies: []string{"not json"}}
+	answer, v := verifyAnswer(context.Background(), model, &types.ChatManage{}, nil, &chat.ChatOptions{}, "Leave is 10 days.")
+	assert.Nil(t, v)
+	assert.Equal(t, "Leave is 10 days.", answer)
+}
@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"mime/multipart"
+	"net/http"
 	"net/textproto"
 	"reflect"
 	"strings"
@@ -489,6 +490,50 @@ func (s *DataSourceService) ManualSync(ctx context.Context, dsID string) (*types
 	return syncLog, nil
 }
 
+// HandleWebhook verifies a change notification pushed by the source of a
+// data source (e.g. a git push webhook) and triggers a sync when it
+// announces changes. Paused data sources and data sources with a sync still
+// running are not synced; the changes are picked up by the next sync.
+func (s *DataSourceService) HandleWebhook(ctx context.Context, dsID string, header http.Header, body []byte) (bool, error) {
+	ds, err := s.dsRepo.FindByID(ctx, dsID)
+	if err != nil || ds == nil {
+		return false, datasource.ErrDataSourceNotFound
+	}
+	connector, err := s.connectorRegistry.Get(ds.Type)
+	if err != nil {
+		return false, err
+	}
+	wc, ok := connector.(datasource.WebhookConnector)
+	if !ok {
+		return false, datasource.ErrWebhookNotSupported
+	}
+	config, err := ds.ParseConfig()
+	if err != nil {
+		return false, err
+	}
+	trigger, err := wc.VerifyWebhook(config, header, body)
+	if err != nil {
+		logger.Warnf(ctx, "webhook rejected for ds=%s: %v", dsID, err)
+		return false, err
+	}
+	if !trigger {
+		return false, nil
+	}
+	if ds.Status != types.DataSourceStatusActive && ds.Status != types.DataSourceStatusError {
+		logger.Infof(ctx, "webhook ignored for ds=%s (status %s)", dsID, ds.Status)
+		return false, nil
+	}
+	if running, _ := s.syncLogRepo.HasRunningSync(ctx, dsID); running {
+		logger.Infof(ctx, "webhook ignored for ds=%s (sync still running)", dsID)
+		return false, nil
+	}
+	if _, err := s.ManualSync(ctx, dsID); err != nil {
+		return false, err
+	}
+	logger.Infof(ctx, "webhook triggered sync for ds=%s", dsID)
+	return true, nil
+}
+
 // PauseDataSource pauses a data source's scheduled syncs
 func (s *DataSourceService) PauseDataSource(ctx context.Context, id string) error {
 	ds, err := s.GetDataSource(ctx, id)
@@ -707,10 +752,17 @@ func (s *DataSourceService) ProcessSync(ctx context.Context, task *asynq.Task) e
 
 	for _, item := range items {
 		if item.IsDeleted {
-			if ds.SyncDeletions {
-				// Count only — actual KB deletion is intentionally not performed.
-				// Users manage knowledge removal explicitly via the KB UI to avoid
-				// accidental data loss from connector misdetection or reconfiguration.
+			// Deletions are propagated only when the user opted in; otherwise
+			// knowledge removed at source stays in the KB until deleted there.
+			if !ds.SyncDeletions {
+				continue
+			}
+			deleted, err := s.deleteItem(ctx, ds, &item)
+			if err != nil {
+				logger.Warnf(ctx, "failed to delete knowledge for removed item (external_id=%s): %v", item.ExternalID, err)
+				result.Failed++
+				result.Errors = append(result.Errors, fmt.Sprintf("delete %s: %v", item.ExternalID, err))
+			} else if deleted {
 				result.Deleted++
 			}
 			continue
@@ -878,8 +930,38 @@ func (s *DataSourceService) validateDataSourceConfig(ctx context.Context, ds *ty
 	return connector.Validate(ctx, config)
 }
 
+// deleteItem removes the knowledge synced from an item deleted at source,
+// located by its external_id. Knowledge created by another data source of
+// the same knowledge base is left alone. Returns whether knowledge was deleted.
+func (s *DataSourceService) deleteItem(ctx context.Context, ds *types.DataSource, item *types.FetchedItem) (bool, error) {
+	if item.ExternalID == "" {
+		return false, nil
+	}
+	repo := s.knowledgeService.GetRepository()
+	existing, err := repo.FindByMetadataKey(ctx, ds.TenantID, ds.KnowledgeBaseID, "external_id", item.ExternalID)
+	if err != nil {
+		return false, fmt.Errorf("find knowledge: %w", err)
+	}
+	if existing == nil {
+		return false, nil
+	}
+	if owner := existing.GetMetadata()["datasource_id"]; owner != "" && owner != ds.ID {
+		logger.Infof(ctx, "knowledge %s for external_id=%s belongs to data source %s, not deleting",
+			existing.ID, item.ExternalID, owner)
+		return false, nil
+	}
+	logger.Infof(ctx, "deleting knowledge %s: external_id=%s was removed at source", existing.ID, item.ExternalID)
+	if err := s.knowledgeService.DeleteKnowledge(ctx, existing.ID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // ingestItem writes a single FetchedItem into the knowledge base.
-// If a knowledge item with the same external_id already exists, it is deleted first (update = delete + re-create).
+// If a file knowledge with the same external_id already exists and the item
+// has content, a new version of its file is uploaded so that only the
+// changed chunks are re-embedded. Otherwise the existing knowledge is deleted
+// first (update = delete + re-create).
 //
 // Routing logic:
 //   - Has Content bytes → CreateKnowledgeFromFile (走完整的文档解析 pipeline)
@@ -888,6 +970,7 @@ func (s *DataSourceService) validateDataSourceConfig(ctx context.Context, ds *ty
 // Returns (isUpdate, error) — isUpdate is true when an existing item was replaced.
 func (s *DataSourceService) ingestItem(ctx context.Context, ds *types.DataSource, item *types.FetchedItem, tagIDs []string) (bool, error) {
 	channel := ds.Type // e.g. "feishu", "notion"
+	ctx = types.WithIngestPriority(ctx, types.IngestPriorityBulk)
 
 	metadata := map[string]string{
 		"external_id":        item.ExternalID,
@@ -907,6 +990,9 @@ func (s *DataSourceService) ingestItem(ctx context.Context, ds *types.DataSource
 			logger.Warnf(ctx, "failed to check existing knowledge for external_id=%s: %v", item.ExternalID, err)
 			// Non-fatal: proceed with creation (may produce duplicate)
 		} else if existing != nil {
+			if updated, err := s.updateItemFile(ctx, existing, item, metadata); updated {
+				return true, err
+			}
 			logger.Infof(ctx, "found existing knowledge %s for external_id=%s, deleting for update", existing.ID, item.ExternalID)
 			if err := s.knowledgeService.DeleteKnowledge(ctx, existing.ID); err != nil {
 				logger.Warnf(ctx, "failed to delete existing knowledge %s: %v", existing.ID, err)
@@ -956,6 +1042,27 @@ func (s *DataSourceService) ingestItem(ctx context.Context, ds *types.DataSource
 	return isUpdate, fmt.Errorf("item has neither content nor URL")
 }
 
+// updateItemFile uploads the content of item as a new version of the file of
+// existing. It reports false when the knowledge cannot be updated in place
+// (not a file knowledge, still processing, ...) and must be re-created.
+func (s *DataSourceService) updateItemFile(
+	ctx context.Context, existing *types.Knowledge, item *types.FetchedItem, metadata map[string]string,
+) (bool, error) {
+	if len(item.Content) == 0 || existing.Type != "file" || existing.FilePath == "" {
+		return false, nil
+	}
+	fh, err := bytesToFileHeader(item.Content, item.FileName)
+	if err != nil {
+		return true, fmt.Errorf("build file header: %w", err)
+	}
+	if _, err := s.knowledgeService.UpdateKnowledgeFile(ctx, existing.ID, fh, item.FileName, metadata); err != nil {
+		logger.Infof(ctx, "cannot update knowledge %s in place, re-creating it: %v", existing.ID, err)
+		return false, nil
+	}
+	logger.Infof(ctx, "updated file of knowledge %s for external_id=%s", existing.ID, item.ExternalID)
+	return true, nil
+}
+
 // bytesToFileHeader wraps a []byte into a *multipart.FileHeader so it can be
 // consumed by KnowledgeService.CreateKnowledgeFromFile.
 func bytesToFileHeader(data []byte, filename string) (*multipart.FileHeader, error) {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"mime/multipart"
 	"strings"
 	"testing"
 	"time"
@@ -184,3 +185,75 @@ func TestAllFetchedItemsFailedErrorTruncatesLongDetail(t *testing.T) {
 	assert.LessOrEqual(t, len(err.Error()), 560)
 	assert.Contains(t, err.Error(), "...")
 }
+
+// deleteItemKnowledgeService records deletions; other KnowledgeService
+// methods are not used by deleteItem.
+type deleteItemKnowledgeService struct {
+	interfaces.KnowledgeService
+	repo    *deleteItemKnowledgeRepo
+	deleted []string
+	updated []string
+}
+
+func (s *deleteItemKnowledgeService) GetRepository() interfaces.KnowledgeRepository { return s.repo }
+
+func (s *deleteItemKnowledgeService) DeleteKnowledge(_ context.Context, id string) error {
+	s.deleted = append(s.deleted, id)
+	return nil
+}
+
+// deleteItemKnowledgeRepo resolves knowledge by external_id.
+type deleteItemKnowledgeRepo struct {
+	interfaces.KnowledgeRepository
+	byExternalID map[string]*types.Knowledge
+}
+
+func (s *deleteItemKnowledgeService) UpdateKnowledgeFile(
+	_ context.Context, id string, _ *multipart.FileHeader, _ string, _ map[string]string,
+) (*types.Knowledge, error) {
+	s.updated = append(s.updated, id)
+	return &types.Knowledge{ID: id}, nil
+}
+
+func (r *deleteItemKnowledgeRepo) FindByMetadataKey(_ context.Context, _ uint64, _ string, _ string, value string) (*types.Knowledge, error) {
+	return r.byExternalID[value], nil
+}
+
+func TestDeleteItemRemovesOwnKnowledgeOnly(t *testing.T) {
+	ks := &deleteItemKnowledgeService{repo: &deleteItemKnowledgeRepo{byExternalID: map[string]*types.Knowledge{
+		"page-1": {ID: "k1", Metadata: types.JSON(`{"external_id":"page-1","datasource_id":"ds-1"}`)},
+		"page-2": {ID: "k2", Metadata: types.JSON(`{"external_id":"page-2","datasource_id":"ds-other"}`)},
+	}}}
+	svc := &DataSourceService{knowledgeService: ks}
+	ds := &types.DataSource{ID: "ds-1", TenantID: 1, KnowledgeBaseID: "kb-1"}
+
+	deleted, err := svc.deleteItem(context.Background(), ds, &types.FetchedItem{ExternalID: "page-1", IsDeleted: true})
+	require.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = svc.deleteItem(context.Background(), ds, &types.FetchedItem{ExternalID: "page-2", IsDeleted: true})
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	deleted, err = svc.deleteItem(context.Background(), ds, &types.FetchedItem{ExternalID: "missing", IsDeleted: true})
+	require.NoError(t, err)
+	assert.False(t, deleted)
+
+	assert.Equal(t, []string{"k1"}, ks.deleted)
+}
+
+func TestIngestItemUpdatesFileKnowledgeInPlace(t *testing.T) {
+	ks := &deleteItemKnowledgeService{repo: &deleteItemKnowledgeRepo{byExternalID: map[string]*types.Knowledge{
+		"page-1": {ID: "k1", Type: "file", FilePath: "local://1/k1/a.md"},
+	}}}
+	svc := &DataSourceService{knowledgeService: ks}
+	ds := &types.DataSource{ID: "ds-1", TenantID: 1, KnowledgeBaseID: "kb-1"}
+
+	isUpdate, err := svc.ingestItem(context.Background(), ds, &types.FetchedItem{
+		ExternalID: "page-1", FileName: "a.md", Content: []byte("# A\n\nnew text"),
+	}, nil)
+	require.NoError(t, err)
+	assert.True(t, isUpdate)
+	assert.Equal(t, []string{"k1"}, ks.updated)
+	assert.Empty(t, ks.deleted, "the knowledge is updated, not re-created")
+}
@@ -0,0 +1,638 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/searchutil"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// duplicateMaxChunks caps the chunks compared by one analysis; every
+	// chunk keeps a MinHash signature in memory while grouping.
+	duplicateMaxChunks = 100000
+	// duplicateMaxGroups caps the groups a report keeps per level.
+	duplicateMaxGroups = 2000
+	// duplicateMinRunes skips chunks whose normalized text is shorter, such
+	// as headings and page numbers that legitimately repeat.
+	duplicateMinRunes = 16
+	// duplicatePreviewRunes is the length of a member's content preview.
+	duplicatePreviewRunes = 120
+	// duplicateAnalysisTimeout bounds one analysis; a report still running
+	// after this long is treated as abandoned.
+	duplicateAnalysisTimeout = 30 * time.Minute
+	// duplicateRemoveBatch is the number of chunks updated per call.
+	duplicateRemoveBatch = 500
+)
+
+// duplicateService finds exact and near duplicate documents and chunks of a
+// knowledge base with MinHash, keeps the report of the latest analysis and
+// disables or deletes the redundant copies on request.
+type duplicateService struct {
+	kbRepo           interfaces.KnowledgeBaseRepository
+	knowledgeRepo    interfaces.KnowledgeRepository
+	chunkRepo        interfaces.ChunkRepository
+	reportRepo       interfaces.DuplicateReportRepository
+	knowledgeService interfaces.KnowledgeService
+	modelService     interfaces.ModelService
+	retrieveEngine   interfaces.RetrieveEngineRegistry
+	ownership        retriever.TenantStoreOwnership
+	task             interfaces.TaskEnqueuer
+}
+
+// NewDuplicateService creates the duplicate content service.
+func NewDuplicateService(
+	kbRepo interfaces.KnowledgeBaseRepository,
+	knowledgeRepo interfaces.KnowledgeRepository,
+	chunkRepo interfaces.ChunkRepository,
+	reportRepo interfaces.DuplicateReportRepository,
+	knowledgeService interfaces.KnowledgeService,
+	modelService interfaces.ModelService,
+	retrieveEngine interfaces.RetrieveEngineRegistry,
+	ownership retriever.TenantStoreOwnership,
+	task interfaces.TaskEnqueuer,
+) interfaces.DuplicateService {
+	return &duplicateService{
+		kbRepo:           kbRepo,
+		knowledgeRepo:    knowledgeRepo,
+		chunkRepo:        chunkRepo,
+		reportRepo:       reportRepo,
+		knowledgeService: knowledgeService,
+		modelService:     modelService,
+		retrieveEngine:   retrieveEngine,
+		ownership:        ownership,
+		task:             task,
+	}
+}
+
+func (s *duplicateService) getKnowledgeBase(ctx context.Context, kbID string) (*types.KnowledgeBase, error) {
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil || kb.TenantID != types.MustTenantIDFromContext(ctx) {
+		return nil, werrors.NewNotFoundError("知识库不存在")
+	}
+	if kb.Type == types.KnowledgeBaseTypeFAQ {
+		return nil, werrors.NewBadRequestError("FAQ 知识库不支持重复内容分析")
+	}
+	return kb, nil
+}
+
+// AnalyzeDuplicates enqueues a duplicate analysis of the knowledge base.
+func (s *duplicateService) AnalyzeDuplicates(
+	ctx context.Context, kbID string, req *types.DuplicateAnalysisRequest,
+) (*types.DuplicateReport, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	threshold := types.DefaultDuplicateThreshold
+	if req != nil && req.Threshold != 0 {
+		threshold = req.Threshold
+	}
+	if threshold < types.MinDuplicateThreshold || threshold > 1 {
+		return nil, werrors.NewBadRequestError(
+			fmt.Sprintf("相似度阈值需在 %.1f 到 1 之间", types.MinDuplicateThreshold))
+	}
+
+	existing, err := s.reportRepo.GetReport(ctx, kb.TenantID, kb.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil && existing.Status == types.DuplicateStatusRunning &&
+		time.Since(existing.CreatedAt) < duplicateAnalysisTimeout {
+		return nil, werrors.NewBadRequestError("重复内容分析正在进行中")
+	}
+
+	report := &types.DuplicateReport{
+		KnowledgeBaseID: kb.ID,
+		TenantID:        kb.TenantID,
+		Status:          types.DuplicateStatusRunning,
+		Threshold:       threshold,
+		CreatedAt:       time.Now(),
+	}
+	if err := s.reportRepo.SaveReport(ctx, report); err != nil {
+		return nil, err
+	}
+
+	payload := types.DuplicateAnalysisPayload{
+		TenantID:        kb.TenantID,
+		KnowledgeBaseID: kb.ID,
+		Threshold:       threshold,
+	}
+	langfuse.InjectTracing(ctx, &payload)
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	task := asynq.NewTask(types.TypeDuplicateAnalysis, payloadBytes,
+		asynq.Queue(types.QueueLow), asynq.MaxRetry(1), asynq.Timeout(duplicateAnalysisTimeout))
+	info, err := s.task.Enqueue(task)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to enqueue duplicate analysis task: %v", err)
+		s.failReport(ctx, report, err)
+		return nil, err
+	}
+	logger.Infof(ctx, "Duplicate analysis task enqueued: %s, knowledge base ID: %s", info.ID, kb.ID)
+	return report, nil
+}
+
+// GetDuplicateReport returns the latest duplicate report of the knowledge base.
+func (s *duplicateService) GetDuplicateReport(ctx context.Context, kbID string) (*types.DuplicateReport, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	report, err := s.reportRepo.GetReport(ctx, kb.TenantID, kb.ID)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, werrors.NewNotFoundError("知识库尚未进行重复内容分析")
+	}
+	return report, nil
+}
+
+func (s *duplicateService) failReport(ctx context.Context, report *types.DuplicateReport, cause error) {
+	now := time.Now()
+	report.Status = types.DuplicateStatusFailed
+	report.ErrorMessage = cause.Error()
+	report.CompletedAt = &now
+	if err := s.reportRepo.SaveReport(ctx, report); err != nil {
+		logger.Warnf(ctx, "Failed to save duplicate report of %s: %v", report.KnowledgeBaseID, err)
+	}
+}
+
+// ProcessDuplicateAnalysis compares the enabled text chunks of the KB's
+// parsed documents, first document against document and then chunk against
+// chunk, and replaces the stored report.
+func (s *duplicateService) ProcessDuplicateAnalysis(ctx context.Context, t *asynq.Task) error {
+	var payload types.DuplicateAnalysisPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "Failed to unmarshal duplicate analysis payload: %v", err)
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	tenantID, kbID := payload.TenantID, payload.KnowledgeBaseID
+	logger.Infof(ctx, "Processing duplicate analysis for knowledge base: %s", kbID)
+
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	if kb == nil || kb.TenantID != tenantID {
+		logger.Warnf(ctx, "Knowledge base %s not found, skip duplicate analysis", kbID)
+		return nil
+	}
+
+	report := &types.DuplicateReport{
+		KnowledgeBaseID: kbID,
+		TenantID:        tenantID,
+		Status:          types.DuplicateStatusRunning,
+		Threshold:       payload.Threshold,
+		CreatedAt:       time.Now(),
+	}
+	if existing, err := s.reportRepo.GetReport(ctx, tenantID, kbID); err == nil && existing != nil {
+		report.CreatedAt = existing.CreatedAt
+	}
+	if err := s.analyze(ctx, report); err != nil {
+		s.failReport(ctx, report, err)
+		return err
+	}
+	now := time.Now()
+	report.Status = types.DuplicateStatusCompleted
+	report.CompletedAt = &now
+	if err := s.reportRepo.SaveReport(ctx, report); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Duplicate analysis of %s done: %d chunks, %d redundant documents, %d redundant chunks",
+		kbID, report.ChunkCount, report.RedundantDocumentCount, report.RedundantChunkCount)
+	return nil
+}
+
+// duplicateChunk is a chunk taking part in an analysis.
+type duplicateChunk struct {
+	knowledge *types.Knowledge
+	chunk     *types.Chunk
+	item      searchutil.DedupItem
+}
+
+// duplicateDocument is a document taking part in an analysis; its signature
+// is the merge of its chunks'.
+type duplicateDocument struct {
+	knowledge *types.Knowledge
+	chunks    []*duplicateChunk
+	item      searchutil.DedupItem
+}
+
+func (s *duplicateService) analyze(ctx context.Context, report *types.DuplicateReport) error {
+	docs, truncated, err := s.collectDocuments(ctx, report.TenantID, report.KnowledgeBaseID)
+	if err != nil {
+		return err
+	}
+	report.Truncated = truncated
+	report.DocumentCount = len(docs)
+
+	docItems := make([]searchutil.DedupItem, len(docs))
+	for i, d := range docs {
+		docItems[i] = d.item
+		report.ChunkCount += len(d.chunks)
+	}
+	redundantDocs := make(map[int]bool)
+	report.DocumentGroups = nil
+	for _, cluster := range searchutil.FindDuplicates(docItems, report.Threshold) {
+		for _, m := range cluster.Duplicates {
+			redundantDocs[m.Index] = true
+		}
+		if len(report.DocumentGroups) >= duplicateMaxGroups {
+			report.Truncated = true
+			continue
+		}
+		group := &types.DuplicateGroup{
+			Kind: duplicateKind(cluster),
+			Keep: *documentMember(docs[cluster.Keep], 1),
+		}
+		for _, m := range cluster.Duplicates {
+			group.Duplicates = append(group.Duplicates, documentMember(docs[m.Index], m.Similarity))
+		}
+		report.DocumentGroups = append(report.DocumentGroups, group)
+		report.RedundantDocumentCount += len(group.Duplicates)
+	}
+
+	// Chunks of redundant documents are covered by their document group.
+	var chunks []*duplicateChunk
+	for i, d := range docs {
+		if !redundantDocs[i] {
+			chunks = append(chunks, d.chunks...)
+		}
+	}
+	chunkItems := make([]searchutil.DedupItem, len(chunks))
+	for i, c := range chunks {
+		chunkItems[i] = c.item
+	}
+	report.ChunkGroups = nil
+	for _, cluster := range searchutil.FindDuplicates(chunkItems, report.Threshold) {
+		if len(report.ChunkGroups) >= duplicateMaxGroups {
+			report.Truncated = true
+			break
+		}
+		group := &types.DuplicateGroup{
+			Kind: duplicateKind(cluster),
+			Keep: *chunkMember(chunks[cluster.Keep], 1),
+		}
+		for _, m := range cluster.Duplicates {
+			group.Duplicates = append(group.Duplicates, chunkMember(chunks[m.Index], m.Similarity))
+		}
+		report.ChunkGroups = append(report.ChunkGroups, group)
+		report.RedundantChunkCount += len(group.Duplicates)
+	}
+	return nil
+}
+
+// collectDocuments loads the enabled text chunks of the KB's parsed
+// documents, oldest document first so the earliest copy is the one kept.
+func (s *duplicateService) collectDocuments(
+	ctx context.Context, tenantID uint64, kbID string,
+) ([]*duplicateDocument, bool, error) {
+	knowledges, err := s.knowledgeRepo.ListKnowledgeByKnowledgeBaseID(ctx, tenantID, kbID)
+	if err != nil {
+		return nil, false, err
+	}
+	sort.SliceStable(knowledges, func(i, j int) bool {
+		return knowledges[i].CreatedAt.Before(knowledges[j].CreatedAt)
+	})
+
+	var docs []*duplicateDocument
+	total := 0
+	for _, k := range knowledges {
+		if k.Type == types.KnowledgeTypeGlossary || k.Type == types.KnowledgeTypeGraphCommunity ||
+			k.Type == types.KnowledgeTypeFAQ || k.ParseStatus != types.ParseStatusCompleted {
+			continue
+		}
+		chunks, err := s.chunkRepo.ListChunksByKnowledgeID(ctx, tenantID, k.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].ChunkIndex < chunks[j].ChunkIndex })
+
+		doc := &duplicateDocument{knowledge: k}
+		var content strings.Builder
+		var signature searchutil.MinHashSignature
+		for _, c := range chunks {
+			if c.ChunkType != types.ChunkTypeText || !c.IsEnabled {
+				continue
+			}
+			if total >= duplicateMaxChunks {
+				return docs, true, nil
+			}
+			total++
+			normalized := searchutil.NormalizeForDedup(c.Content)
+			if len([]rune(normalized)) < duplicateMinRunes {
+				continue
+			}
+			sig := searchutil.NewMinHashSignature(normalized)
+			doc.chunks = append(doc.chunks, &duplicateChunk{
+				knowledge: k,
+				chunk:     c,
+				item: searchutil.DedupItem{
+					Fingerprint: searchutil.DedupFingerprint(normalized),
+					Signature:   sig,
+				},
+			})
+			content.WriteString(normalized)
+			if signature == nil {
+				signature = append(searchutil.MinHashSignature(nil), sig...)
+			} else {
+				signature.Merge(sig)
+			}
+		}
+		if len(doc.chunks) == 0 {
+			continue
+		}
+		doc.item = searchutil.DedupItem{
+			Fingerprint: searchutil.DedupFingerprint(content.String()),
+			Signature:   signature,
+		}
+		docs = append(docs, doc)
+	}
+	return docs, false, nil
+}
+
+func duplicateKind(cluster searchutil.DedupCluster) string {
+	for _, m := range cluster.Duplicates {
+		if !m.Exact {
+			return types.DuplicateKindNear
+		}
+	}
+	return types.DuplicateKindExact
+}
+
+func documentMember(d *duplicateDocument, similarity float64) *types.DuplicateMember {
+	return &types.DuplicateMember{
+		KnowledgeID:    d.knowledge.ID,
+		KnowledgeTitle: d.knowledge.Title,
+		Similarity:     similarity,
+		Fingerprint:    d.item.Fingerprint,
+	}
+}
+
+func chunkMember(c *duplicateChunk, similarity float64) *types.DuplicateMember {
+	preview := []rune(c.chunk.Content)
+	if len(preview) > duplicatePreviewRunes {
+		preview = preview[:duplicatePreviewRunes]
+	}
+	return &types.DuplicateMember{
+		KnowledgeID:    c.knowledge.ID,
+		KnowledgeTitle: c.knowledge.Title,
+		ChunkID:        c.chunk.ID,
+		ChunkIndex:     c.chunk.ChunkIndex,
+		Preview:        string(preview),
+		Similarity:     similarity,
+		Fingerprint:    c.item.Fingerprint,
+	}
+}
+
+// RemoveDuplicates disables or deletes the redundant copies of the latest
+// report. A copy is left alone when it or its kept copy was deleted,
+// disabled or edited since the analysis, so a stale report never removes the
+// last copy of some content.
+func (s *duplicateService) RemoveDuplicates(
+	ctx context.Context, kbID string, req *types.DuplicateRemoveRequest,
+) (*types.DuplicateRemoveResult, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	action := types.DuplicateActionDisable
+	if req != nil && req.Action != "" {
+		action = req.Action
+	}
+	if action != types.DuplicateActionDisable && action != types.DuplicateActionDelete {
+		return nil, werrors.NewBadRequestError("不支持的去重操作: " + action)
+	}
+	report, err := s.reportRepo.GetReport(ctx, kb.TenantID, kb.ID)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil || report.Status != types.DuplicateStatusCompleted {
+		return nil, werrors.NewBadRequestError("没有已完成的重复内容分析报告")
+	}
+
+	result := &types.DuplicateRemoveResult{Action: action}
+	var redundant []*types.Chunk
+	if req == nil || !req.SkipDocuments {
+		chunks, err := s.removeDocuments(ctx, kb, report.DocumentGroups, action, result)
+		if err != nil {
+			return nil, err
+		}
+		redundant = append(redundant, chunks...)
+	}
+	if req == nil || !req.SkipChunks {
+		chunks, err := s.redundantChunks(ctx, kb, report.ChunkGroups, result)
+		if err != nil {
+			return nil, err
+		}
+		redundant = append(redundant, chunks...)
+	}
+	if err := s.removeChunks(ctx, kb, redundant, action, result); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	report.RemovedAt = &now
+	if err := s.reportRepo.SaveReport(ctx, report); err != nil {
+		logger.Warnf(ctx, "Failed to save duplicate report of %s: %v", kb.ID, err)
+	}
+	logger.Infof(ctx, "Duplicates of %s removed (%s): %d documents deleted, %d chunks disabled, %d chunks deleted, %d skipped",
+		kb.ID, action, result.DocumentsDeleted, result.ChunksDisabled, result.ChunksDeleted, result.Skipped)
+	return result, nil
+}
+
+// removeDocuments deletes the redundant documents, or returns their enabled
+// text chunks when they are to be disabled.
+func (s *duplicateService) removeDocuments(
+	ctx context.Context, kb *types.KnowledgeBase, groups types.DuplicateGroups,
+	action string, result *types.DuplicateRemoveResult,
+) ([]*types.Chunk, error) {
+	var chunks []*types.Chunk
+	for _, group := range groups {
+		keep, err := s.currentDocument(ctx, kb, &group.Keep)
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range group.Duplicates {
+			if keep == nil {
+				result.Skipped++
+				continue
+			}
+			dup, err := s.currentDocument(ctx, kb, member)
+			if err != nil {
+				return nil, err
+			}
+			if dup == nil {
+				result.Skipped++
+				continue
+			}
+			if action == types.DuplicateActionDelete {
+				if err := s.knowledgeService.DeleteKnowledge(ctx, dup.ID); err != nil {
+					return nil, err
+				}
+				result.DocumentsDeleted++
+				continue
+			}
+			docChunks, err := s.chunkRepo.ListChunksByKnowledgeID(ctx, kb.TenantID, dup.ID)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range docChunks {
+				if c.ChunkType == types.ChunkTypeText && c.IsEnabled {
+					chunks = append(chunks, c)
+				}
+			}
+		}
+	}
+	return chunks, nil
+}
+
+// currentDocument returns the knowledge of a document member if it is still
+// in the knowledge base with the content it had at analysis time.
+func (s *duplicateService) currentDocument(
+	ctx context.Context, kb *types.KnowledgeBase, member *types.DuplicateMember,
+) (*types.Knowledge, error) {
+	k, err := s.knowledgeRepo.GetKnowledgeByID(ctx, kb.TenantID, member.KnowledgeID)
+	if err != nil || k == nil || k.KnowledgeBaseID != kb.ID {
+		return nil, nil
+	}
+	chunks, err := s.chunkRepo.ListChunksByKnowledgeID(ctx, kb.TenantID, k.ID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].ChunkIndex < chunks[j].ChunkIndex })
+	var content strings.Builder
+	for _, c := range chunks {
+		if c.ChunkType != types.ChunkTypeText || !c.IsEnabled {
+			continue
+		}
+		if normalized := searchutil.NormalizeForDedup(c.Content); len([]rune(normalized)) >= duplicateMinRunes {
+			content.WriteString(normalized)
+		}
+	}
+	if content.Len() == 0 || searchutil.DedupFingerprint(content.String()) != member.Fingerprint {
+		return nil, nil
+	}
+	return k, nil
+}
+
+// redundantChunks returns the duplicate chunks of the groups that, like
+// their kept chunk, are unchanged since the analysis.
+func (s *duplicateService) redundantChunks(
+	ctx context.Context, kb *types.KnowledgeBase, groups types.DuplicateGroups,
+	result *types.DuplicateRemoveResult,
+) ([]*types.Chunk, error) {
+	var ids []string
+	for _, group := range groups {
+		ids = append(ids, group.Keep.ChunkID)
+		for _, member := range group.Duplicates {
+			ids = append(ids, member.ChunkID)
+		}
+	}
+	current := make(map[string]*types.Chunk, len(ids))
+	for start := 0; start < len(ids); start += duplicateRemoveBatch {
+		chunks, err := s.chunkRepo.ListChunksByID(ctx, kb.TenantID, ids[start:min(start+duplicateRemoveBatch, len(ids))])
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range chunks {
+			current[c.ID] = c
+		}
+	}
+	unchanged := func(member *types.DuplicateMember) *types.Chunk {
+		c := current[member.ChunkID]
+		if c == nil || c.KnowledgeBaseID != kb.ID || !c.IsEnabled ||
+			searchutil.DedupFingerprint(searchutil.NormalizeForDedup(c.Content)) != member.Fingerprint {
+			return nil
+		}
+		return c
+	}
+
+	var redundant []*types.Chunk
+	for _, group := range groups {
+		if unchanged(&group.Keep) == nil {
+			result.Skipped += len(group.Duplicates)
+			continue
+		}
+		for _, member := range group.Duplicates {
+			if c := unchanged(member); c != nil {
+				redundant = append(redundant, c)
+			} else {
+				result.Skipped++
+			}
+		}
+	}
+	return redundant, nil
+}
+
+// removeChunks disables or deletes chunks together with their index rows.
+func (s *duplicateService) removeChunks(
+	ctx context.Context, kb *types.KnowledgeBase, chunks []*types.Chunk,
+	action string, result *types.DuplicateRemoveResult,
+) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	retrieveEngine, err := retriever.CreateRetrieveEngineForKB(
+		ctx, s.retrieveEngine, s.ownership, kb.TenantID, kb.VectorStoreID)
+	if err != nil {
+		return err
+	}
+	dimension := 0
+	if action == types.DuplicateActionDelete {
+		embeddingModel, err := s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+		if err != nil {
+			return err
+		}
+		dimension = embeddingModel.GetDimensions()
+	}
+
+	for start := 0; start < len(chunks); start += duplicateRemoveBatch {
+		batch := chunks[start:min(start+duplicateRemoveBatch, len(chunks))]
+		ids := make([]string, len(batch))
+		for i, c := range batch {
+			ids[i] = c.ID
+		}
+		if action == types.DuplicateActionDelete {
+			if err := retrieveEngine.DeleteByChunkIDList(ctx, ids, dimension, kb.Type); err != nil {
+				return err
+			}
+			if err := s.chunkRepo.DeleteChunks(ctx, kb.TenantID, ids); err != nil {
+				return err
+			}
+			result.ChunksDeleted += len(batch)
+			continue
+		}
+		status := make(map[string]bool, len(batch))
+		for _, c := range batch {
+			c.IsEnabled = false
+			c.UpdatedAt = time.Now()
+			status[c.ID] = false
+		}
+		if err := s.chunkRepo.UpdateChunks(ctx, batch); err != nil {
+			return err
+		}
+		if err := retrieveEngine.BatchUpdateChunkEnabledStatus(ctx, status); err != nil {
+			return err
+		}
+		result.ChunksDisabled += len(batch)
+	}
+	return nil
+}
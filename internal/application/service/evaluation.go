@@ -266,33 +266,7 @@ func (e *EvaluationService) Evaluation(ctx context.Context,
 			StartTime: time.Now(),
 		},
 		Params: &types.ChatManage{
-			PipelineRequest: types.PipelineRequest{
-				VectorThreshold:  e.config.Conversation.VectorThreshold,
-				KeywordThreshold: e.config.Conversation.KeywordThreshold,
-				EmbeddingTopK:    e.config.Conversation.EmbeddingTopK,
-				MaxRounds:        e.config.Conversation.MaxRounds,
-				RerankModelID:    rerankModelID,
-				RerankTopK:       e.config.Conversation.RerankTopK,
-				RerankThreshold:  e.config.Conversation.RerankThreshold,
-				ChatModelID:      chatModelID,
-				SummaryConfig: types.SummaryConfig{
-					MaxTokens:           e.config.Conversation.Summary.MaxTokens,
-					RepeatPenalty:       e.config.Conversation.Summary.RepeatPenalty,
-					TopK:                e.config.Conversation.Summary.TopK,
-					TopP:                e.config.Conversation.Summary.TopP,
-					Prompt:              e.config.Conversation.Summary.Prompt,
-					ContextTemplate:     e.config.Conversation.Summary.ContextTemplate,
-					FrequencyPenalty:    e.config.Conversation.Summary.FrequencyPenalty,
-					PresencePenalty:     e.config.Conversation.Summary.PresencePenalty,
-					NoMatchPrefix:       e.config.Conversation.Summary.NoMatchPrefix,
-					Temperature:         e.config.Conversation.Summary.Temperature,
-					Seed:                e.config.Conversation.Summary.Seed,
-					MaxCompletionTokens: e.config.Conversation.Summary.MaxCompletionTokens,
-				},
-				FallbackResponse:    e.config.Conversation.FallbackResponse,
-				RewritePromptSystem: e.config.Conversation.RewritePromptSystem,
-				RewritePromptUser:   e.config.Conversation.RewritePromptUser,
-			},
+			PipelineRequest: conversationPipelineRequest(e.config, chatModelID, rerankModelID),
 		},
 	}
 
@@ -455,6 +429,38 @@ func (e *EvaluationService) EvalDataset(ctx context.Context, detail *types.Evalu
 	return nil
 }
 
+// conversationPipelineRequest returns the pipeline parameters of the
+// configured conversation defaults with the given chat and rerank models
+func conversationPipelineRequest(cfg *config.Config, chatModelID, rerankModelID string) types.PipelineRequest {
+	return types.PipelineRequest{
+		VectorThreshold:  cfg.Conversation.VectorThreshold,
+		KeywordThreshold: cfg.Conversation.KeywordThreshold,
+		EmbeddingTopK:    cfg.Conversation.EmbeddingTopK,
+		MaxRounds:        cfg.Conversation.MaxRounds,
+		RerankModelID:    rerankModelID,
+		RerankTopK:       cfg.Conversation.RerankTopK,
+		RerankThreshold:  cfg.Conversation.RerankThreshold,
+		ChatModelID:      chatModelID,
+		SummaryConfig: types.SummaryConfig{
+			MaxTokens:           cfg.Conversation.Summary.MaxTokens,
+			RepeatPenalty:       cfg.Conversation.Summary.RepeatPenalty,
+			TopK:                cfg.Conversation.Summary.TopK,
+			TopP:                cfg.Conversation.Summary.TopP,
+			Prompt:              cfg.Conversation.Summary.Prompt,
+			ContextTemplate:     cfg.Conversation.Summary.ContextTemplate,
+			FrequencyPenalty:    cfg.Conversation.Summary.FrequencyPenalty,
+			PresencePenalty:     cfg.Conversation.Summary.PresencePenalty,
+			NoMatchPrefix:       cfg.Conversation.Summary.NoMatchPrefix,
+			Temperature:         cfg.Conversation.Summary.Temperature,
+			Seed:                cfg.Conversation.Summary.Seed,
+			MaxCompletionTokens: cfg.Conversation.Summary.MaxCompletionTokens,
+		},
+		FallbackResponse:    cfg.Conversation.FallbackResponse,
+		RewritePromptSystem: cfg.Conversation.RewritePromptSystem,
+		RewritePromptUser:   cfg.Conversation.RewritePromptUser,
+	}
+}
+
 // getPassageList extracts and organizes passages from QA pairs
 // Returns a slice of passages indexed by their passage IDs
 func getPassageList(dataset []*types.QAPair) []string {
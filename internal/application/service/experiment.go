@@ -0,0 +1,609 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// experimentJudgeMaxContextRunes bounds the retrieved context of each
+// answer shown to the judge
+const experimentJudgeMaxContextRunes = 4000
+
+const experimentJudgePrompt = `You are comparing two answers to the same question, each produced by a retrieval-augmented assistant from its own retrieved context.
+
+Decide which answer is better: more correct, more complete and better supported by its retrieved context. An answer that says it cannot answer beats one that makes unsupported claims. Ignore length and style unless they hurt clarity. Answer "tie" when neither is clearly better.
+
+Respond with a JSON object only, no commentary:
+{"winner": "A" | "B" | "tie", "reason": "<one sentence>"}
+
+Question: {{question}}
+
+Context retrieved for answer A:
+{{context_a}}
+
+Answer A:
+{{answer_a}}
+
+Context retrieved for answer B:
+{{context_b}}
+
+Answer B:
+{{answer_b}}`
+
+// experimentService runs alternative pipeline configurations in the shadow
+// of production. When a production answer completes, each running
+// experiment of the tenant samples it with its sample rate; a sampled
+// question is answered again in a background task with the experiment's
+// configuration, which never reaches the user or the session, and a judge
+// model compares the two answers.
+type experimentService struct {
+	config         *config.Config
+	repo           interfaces.ExperimentRepository
+	kbRepo         interfaces.KnowledgeBaseRepository
+	knowledgeRepo  interfaces.KnowledgeRepository
+	messageRepo    interfaces.MessageRepository
+	tenantRepo     interfaces.TenantRepository
+	modelService   interfaces.ModelService
+	sessionService interfaces.SessionService
+	task           interfaces.TaskEnqueuer
+}
+
+// NewExperimentService creates the shadow experiment service.
+func NewExperimentService(
+	config *config.Config,
+	repo interfaces.ExperimentRepository,
+	kbRepo interfaces.KnowledgeBaseRepository,
+	knowledgeRepo interfaces.KnowledgeRepository,
+	messageRepo interfaces.MessageRepository,
+	tenantRepo interfaces.TenantRepository,
+	modelService interfaces.ModelService,
+	sessionService interfaces.SessionService,
+	task interfaces.TaskEnqueuer,
+) interfaces.ExperimentService {
+	return &experimentService{
+		config:         config,
+		repo:           repo,
+		kbRepo:         kbRepo,
+		knowledgeRepo:  knowledgeRepo,
+		messageRepo:    messageRepo,
+		tenantRepo:     tenantRepo,
+		modelService:   modelService,
+		sessionService: sessionService,
+		task:           task,
+	}
+}
+
+// CreateExperiment creates a running experiment in the caller's tenant
+func (s *experimentService) CreateExperiment(
+	ctx context.Context, req *types.ExperimentRequest,
+) (*types.Experiment, error) {
+	if err := req.Validate(); err != nil {
+		return nil, werrors.NewBadRequestError("实验参数不合法").WithDetails(err.Error())
+	}
+	tenantID := types.MustTenantIDFromContext(ctx)
+	for _, kbID := range req.KnowledgeBaseIDs {
+		kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+		if err != nil || kb == nil || kb.TenantID != tenantID {
+			return nil, werrors.NewNotFoundError("知识库不存在").WithDetails(kbID)
+		}
+	}
+	for _, modelID := range []string{req.Config.RerankModelID, req.Config.ChatModelID, req.Config.JudgeModelID} {
+		if modelID == "" {
+			continue
+		}
+		if model, err := s.modelService.GetModelByID(ctx, modelID); err != nil || model == nil {
+			return nil, werrors.NewBadRequestError("模型不存在").WithDetails(modelID)
+		}
+	}
+
+	userID, _ := types.UserIDFromContext(ctx)
+	experiment := &types.Experiment{
+		ID:               uuid.New().String(),
+		TenantID:         tenantID,
+		Name:             req.Name,
+		Description:      req.Description,
+		KnowledgeBaseIDs: req.KnowledgeBaseIDs,
+		SamplePercent:    req.SamplePercent,
+		MaxSamples:       req.MaxSamples,
+		Status:           types.ExperimentStatusRunning,
+		Config:           req.Config,
+		CreatedBy:        userID,
+	}
+	if err := s.repo.CreateExperiment(ctx, experiment); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Created shadow experiment %s sampling %.2f%% of answers, budget %d",
+		experiment.ID, experiment.SamplePercent, experiment.MaxSamples)
+	experiment.Report = &types.ExperimentReport{}
+	return experiment, nil
+}
+
+// ListExperiments returns the experiments of the caller's tenant with their
+// reports, newest first
+func (s *experimentService) ListExperiments(ctx context.Context) ([]*types.Experiment, error) {
+	experiments, err := s.repo.ListExperiments(ctx, types.MustTenantIDFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachReports(ctx, experiments...); err != nil {
+		return nil, err
+	}
+	return experiments, nil
+}
+
+// GetExperiment returns an experiment with its report
+func (s *experimentService) GetExperiment(ctx context.Context, id string) (*types.Experiment, error) {
+	experiment, err := s.getExperiment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attachReports(ctx, experiment); err != nil {
+		return nil, err
+	}
+	return experiment, nil
+}
+
+func (s *experimentService) getExperiment(ctx context.Context, id string) (*types.Experiment, error) {
+	experiment, err := s.repo.GetExperiment(ctx, types.MustTenantIDFromContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	if experiment == nil {
+		return nil, werrors.NewNotFoundError("实验不存在")
+	}
+	return experiment, nil
+}
+
+// attachReports sums up the samples of experiments into their reports
+func (s *experimentService) attachReports(ctx context.Context, experiments ...*types.Experiment) error {
+	ids := make([]string, len(experiments))
+	for i, experiment := range experiments {
+		ids[i] = experiment.ID
+	}
+	counts, err := s.repo.CountSamples(ctx, ids)
+	if err != nil {
+		return err
+	}
+	byExperiment := make(map[string][]*types.ExperimentSampleCounts, len(experiments))
+	for _, c := range counts {
+		byExperiment[c.ExperimentID] = append(byExperiment[c.ExperimentID], c)
+	}
+	for _, experiment := range experiments {
+		experiment.Report = types.NewExperimentReport(byExperiment[experiment.ID])
+	}
+	return nil
+}
+
+// UpdateExperiment renames, resizes, pauses or resumes an experiment
+func (s *experimentService) UpdateExperiment(
+	ctx context.Context, id string, req *types.ExperimentUpdateRequest,
+) (*types.Experiment, error) {
+	experiment, err := s.getExperiment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := req.ApplyTo(experiment); err != nil {
+		return nil, werrors.NewBadRequestError("实验参数不合法").WithDetails(err.Error())
+	}
+	if err := s.repo.UpdateExperiment(ctx, experiment); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Updated shadow experiment %s, status: %s", experiment.ID, experiment.Status)
+	if err := s.attachReports(ctx, experiment); err != nil {
+		return nil, err
+	}
+	return experiment, nil
+}
+
+// DeleteExperiment deletes an experiment with its samples
+func (s *experimentService) DeleteExperiment(ctx context.Context, id string) error {
+	if _, err := s.getExperiment(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteExperiment(ctx, types.MustTenantIDFromContext(ctx), id)
+}
+
+// ListSamples returns a page of the samples of an experiment, newest first;
+// winner filters them by verdict, "failed" for samples without one
+func (s *experimentService) ListSamples(
+	ctx context.Context, id string, winner string, page *types.Pagination,
+) (*types.PageResult, error) {
+	experiment, err := s.getExperiment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	var filter *types.ExperimentWinner
+	switch w := types.ExperimentWinner(winner); w {
+	case "":
+	case types.ExperimentWinnerProduction, types.ExperimentWinnerShadow, types.ExperimentWinnerTie:
+		filter = &w
+	case "failed":
+		none := types.ExperimentWinner("")
+		filter = &none
+	default:
+		return nil, werrors.NewBadRequestError(fmt.Sprintf("不支持的评判结果：%s", winner))
+	}
+	samples, total, err := s.repo.ListSamples(ctx, experiment.ID, filter, page)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewPageResult(total, page, samples), nil
+}
+
+// ObserveAnswer offers a completed production answer to the running
+// experiments of the caller's tenant. Each experiment in scope samples it
+// with its sample rate and, while its budget lasts, enqueues a shadow
+// answer. Failures are logged only: experiments must not affect answers.
+func (s *experimentService) ObserveAnswer(ctx context.Context, observation *types.ExperimentObservation) {
+	searched := observation.SearchedKnowledgeBases()
+	if len(searched) == 0 && len(observation.KnowledgeIDs) == 0 {
+		return
+	}
+	tenantID := types.MustTenantIDFromContext(ctx)
+	experiments, err := s.repo.ListRunningExperiments(ctx, tenantID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to list running experiments: %v", err)
+		return
+	}
+	for _, experiment := range experiments {
+		if !experiment.Matches(searched) || rand.Float64()*100 >= experiment.SamplePercent {
+			continue
+		}
+		reserved, err := s.repo.ReserveSample(ctx, tenantID, experiment.ID)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to sample answer %s for experiment %s: %v",
+				observation.MessageID, experiment.ID, err)
+			continue
+		}
+		if !reserved {
+			continue
+		}
+		if err := s.enqueueShadowAnswer(ctx, experiment, observation, searched); err != nil {
+			logger.Warnf(ctx, "Failed to enqueue shadow answer of experiment %s: %v", experiment.ID, err)
+		}
+	}
+}
+
+// enqueueShadowAnswer enqueues the shadow answer of a sampled production
+// answer. The shadow searches what the request named or, when it named
+// nothing, the knowledge bases production retrieved from.
+func (s *experimentService) enqueueShadowAnswer(
+	ctx context.Context, experiment *types.Experiment, observation *types.ExperimentObservation, searched []string,
+) error {
+	knowledgeBaseIDs := observation.KnowledgeBaseIDs
+	if len(knowledgeBaseIDs) == 0 && len(observation.KnowledgeIDs) == 0 {
+		knowledgeBaseIDs = searched
+	}
+	userID, _ := types.UserIDFromContext(ctx)
+	lang, _ := types.LanguageFromContext(ctx)
+	payload := types.ExperimentShadowPayload{
+		TenantID:         experiment.TenantID,
+		ExperimentID:     experiment.ID,
+		SessionID:        observation.SessionID,
+		MessageID:        observation.MessageID,
+		UserID:           userID,
+		Language:         lang,
+		Question:         observation.Question,
+		ChatModelID:      observation.ChatModelID,
+		KnowledgeBaseIDs: knowledgeBaseIDs,
+		KnowledgeIDs:     observation.KnowledgeIDs,
+	}
+	langfuse.InjectTracing(ctx, &payload)
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	info, err := s.task.Enqueue(asynq.NewTask(types.TypeExperimentShadow, payloadBytes),
+		asynq.Queue(types.QueueLow), asynq.MaxRetry(1), asynq.Timeout(10*time.Minute))
+	if err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Shadow answer task enqueued: %s, experiment ID: %s, message ID: %s",
+		info.ID, experiment.ID, observation.MessageID)
+	return nil
+}
+
+// ProcessShadowAnswer answers a sampled question with the experiment's
+// configuration, has the judge compare it with the production answer and
+// stores the sample. A failing shadow pipeline or judge is recorded on the
+// sample rather than retried.
+func (s *experimentService) ProcessShadowAnswer(ctx context.Context, t *asynq.Task) error {
+	var payload types.ExperimentShadowPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	if payload.UserID != "" {
+		ctx = context.WithValue(ctx, types.UserIDContextKey, payload.UserID)
+	}
+	if payload.Language != "" {
+		ctx = context.WithValue(ctx, types.LanguageContextKey, payload.Language)
+	}
+
+	experiment, err := s.repo.GetExperiment(ctx, payload.TenantID, payload.ExperimentID)
+	if err != nil {
+		return err
+	}
+	if experiment == nil {
+		logger.Warnf(ctx, "Experiment %s not found, skip shadow answer", payload.ExperimentID)
+		return nil
+	}
+	message, err := s.messageRepo.GetMessage(ctx, payload.SessionID, payload.MessageID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Warnf(ctx, "Message %s not found, skip shadow answer", payload.MessageID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	tenant, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenant)
+
+	sample := &types.ExperimentSample{
+		ID:                uuid.New().String(),
+		ExperimentID:      experiment.ID,
+		TenantID:          experiment.TenantID,
+		SessionID:         payload.SessionID,
+		MessageID:         payload.MessageID,
+		Question:          payload.Question,
+		ProductionAnswer:  message.Content,
+		ProductionSources: types.NewExperimentSources(message.KnowledgeReferences),
+	}
+	if err := s.answerInShadow(ctx, experiment, &payload, message, sample); err != nil {
+		// A cancelled worker leaves the sample to the retry
+		if ctx.Err() != nil {
+			return err
+		}
+		logger.Warnf(ctx, "Experiment %s: shadow answer of message %s failed: %v",
+			experiment.ID, payload.MessageID, err)
+		sample.ErrMsg = err.Error()
+	}
+	if err := s.repo.SaveSample(ctx, sample); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Experiment %s sampled message %s, winner: %q",
+		experiment.ID, payload.MessageID, sample.Winner)
+	return nil
+}
+
+// answerInShadow runs the experiment's pipeline for the sampled question
+// and judges the result against the production answer, filling sample.
+func (s *experimentService) answerInShadow(
+	ctx context.Context, experiment *types.Experiment, payload *types.ExperimentShadowPayload,
+	message *types.Message, sample *types.ExperimentSample,
+) error {
+	chatManage, err := s.shadowChatManage(ctx, experiment, payload)
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	err = s.sessionService.KnowledgeQAByEvent(ctx, chatManage, types.Pipeline["rag"])
+	sample.ShadowLatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		return err
+	}
+	if chatManage.ChatResponse != nil {
+		sample.ShadowAnswer = chatManage.ChatResponse.Content
+	}
+	sample.ShadowSources = types.NewExperimentSources(chatManage.MergeResult)
+	sample.SourceOverlap = sample.ProductionSources.Overlap(sample.ShadowSources)
+
+	judgeModelID := experiment.Config.JudgeModelID
+	if judgeModelID == "" {
+		judgeModelID = chatManage.ChatModelID
+	}
+	judge, err := s.modelService.GetChatModel(ctx, judgeModelID)
+	if err != nil {
+		sample.JudgeReason = "judge failed: " + err.Error()
+		return nil
+	}
+	winner, reason, err := judgeExperimentSample(ctx, judge, sample.Question,
+		message.KnowledgeReferences, sample.ProductionAnswer, chatManage.MergeResult, sample.ShadowAnswer)
+	if err != nil {
+		logger.Warnf(ctx, "Experiment %s: failed to judge message %s: %v", experiment.ID, sample.MessageID, err)
+		sample.JudgeReason = "judge failed: " + err.Error()
+		return nil
+	}
+	sample.Winner = winner
+	sample.JudgeReason = reason
+	return nil
+}
+
+// shadowChatManage builds the pipeline parameters of a shadow answer: the
+// conversation defaults, overridden by the experiment's configuration,
+// searching the knowledge bases of the sampled answer that belong to the
+// experiment's tenant.
+func (s *experimentService) shadowChatManage(
+	ctx context.Context, experiment *types.Experiment, payload *types.ExperimentShadowPayload,
+) (*types.ChatManage, error) {
+	targets, kbs, err := s.shadowSearchTargets(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, errors.New("no knowledge base of the tenant to search")
+	}
+
+	cfg := &experiment.Config
+	chatModelID := cfg.ChatModelID
+	if chatModelID == "" {
+		chatModelID = payload.ChatModelID
+	}
+	for _, kb := range kbs {
+		if chatModelID != "" {
+			break
+		}
+		chatModelID = kb.SummaryModelID
+	}
+	if chatModelID == "" {
+		return nil, errors.New("no chat model to answer with")
+	}
+
+	req := conversationPipelineRequest(s.config, chatModelID, cfg.RerankModelID)
+	if cfg.EmbeddingTopK > 0 {
+		req.EmbeddingTopK = cfg.EmbeddingTopK
+	}
+	if cfg.VectorThreshold != nil {
+		req.VectorThreshold = *cfg.VectorThreshold
+	}
+	if cfg.KeywordThreshold != nil {
+		req.KeywordThreshold = *cfg.KeywordThreshold
+	}
+	if cfg.RerankTopK > 0 {
+		req.RerankTopK = cfg.RerankTopK
+	}
+	if cfg.RerankThreshold != nil {
+		req.RerankThreshold = *cfg.RerankThreshold
+	}
+	req.Query = payload.Question
+	req.UserID = payload.UserID
+	req.TenantID = payload.TenantID
+	req.KnowledgeBaseIDs = targets.GetAllKnowledgeBaseIDs()
+	req.KnowledgeIDs = payload.KnowledgeIDs
+	req.SearchTargets = targets
+	req.Language = types.LanguageNameFromContext(ctx)
+	return &types.ChatManage{
+		PipelineRequest: req,
+		PipelineState:   types.PipelineState{RewriteQuery: payload.Question},
+	}, nil
+}
+
+// shadowSearchTargets resolves the knowledge bases and documents of a
+// sampled answer into search targets. Knowledge bases shared from other
+// tenants are left out: their owners did not opt into the experiment.
+func (s *experimentService) shadowSearchTargets(
+	ctx context.Context, payload *types.ExperimentShadowPayload,
+) (types.SearchTargets, []*types.KnowledgeBase, error) {
+	var targets types.SearchTargets
+	var kbs []*types.KnowledgeBase
+	full := make(map[string]bool, len(payload.KnowledgeBaseIDs))
+	for _, kbID := range payload.KnowledgeBaseIDs {
+		kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+		if err != nil || kb == nil || kb.TenantID != payload.TenantID {
+			continue
+		}
+		full[kb.ID] = true
+		kbs = append(kbs, kb)
+		targets = append(targets, &types.SearchTarget{
+			Type:            types.SearchTargetTypeKnowledgeBase,
+			KnowledgeBaseID: kb.ID,
+			TenantID:        kb.TenantID,
+		})
+	}
+	if len(payload.KnowledgeIDs) == 0 {
+		return targets, kbs, nil
+	}
+
+	knowledgeList, err := s.knowledgeRepo.GetKnowledgeBatch(ctx, payload.TenantID, payload.KnowledgeIDs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get knowledge: %w", err)
+	}
+	byKB := make(map[string]*types.SearchTarget)
+	for _, k := range knowledgeList {
+		if k == nil || k.KnowledgeBaseID == "" || full[k.KnowledgeBaseID] {
+			continue
+		}
+		target, ok := byKB[k.KnowledgeBaseID]
+		if !ok {
+			kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, k.KnowledgeBaseID)
+			if err != nil || kb == nil || kb.TenantID != payload.TenantID {
+				continue
+			}
+			kbs = append(kbs, kb)
+			target = &types.SearchTarget{
+				Type:            types.SearchTargetTypeKnowledge,
+				KnowledgeBaseID: kb.ID,
+				TenantID:        kb.TenantID,
+			}
+			byKB[kb.ID] = target
+			targets = append(targets, target)
+		}
+		target.KnowledgeIDs = append(target.KnowledgeIDs, k.ID)
+	}
+	return targets, kbs, nil
+}
+
+// judgeExperimentSample has the judge model compare the production and the
+// shadow answer. The answers are shown in random order so the judge's
+// position bias does not favour either side.
+func judgeExperimentSample(
+	ctx context.Context, judge chat.Chat, question string,
+	productionContext []*types.SearchResult, productionAnswer string,
+	shadowContext []*types.SearchResult, shadowAnswer string,
+) (types.ExperimentWinner, string, error) {
+	first, second := types.ExperimentWinnerProduction, types.ExperimentWinnerShadow
+	values := types.PlaceholderValues{
+		"question":  question,
+		"context_a": judgeContext(productionContext, experimentJudgeMaxContextRunes),
+		"answer_a":  productionAnswer,
+		"context_b": judgeContext(shadowContext, experimentJudgeMaxContextRunes),
+		"answer_b":  shadowAnswer,
+	}
+	if rand.Intn(2) == 1 {
+		first, second = second, first
+		values["context_a"], values["context_b"] = values["context_b"], values["context_a"]
+		values["answer_a"], values["answer_b"] = values["answer_b"], values["answer_a"]
+	}
+	prompt := types.RenderPromptPlaceholders(experimentJudgePrompt, values)
+
+	thinking := false
+	resp, err := judge.Chat(ctx, []chat.Message{{Role: "user", Content: prompt}}, &chat.ChatOptions{
+		Temperature: 0,
+		MaxTokens:   512,
+		Thinking:    &thinking,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	var verdict struct {
+		Winner string `json:"winner"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(cleanLLMJSON(resp.Content)), &verdict); err != nil {
+		return "", "", fmt.Errorf("parse verdict: %w", err)
+	}
+	switch strings.ToLower(strings.TrimSpace(verdict.Winner)) {
+	case "a":
+		return first, verdict.Reason, nil
+	case "b":
+		return second, verdict.Reason, nil
+	case "tie":
+		return types.ExperimentWinnerTie, verdict.Reason, nil
+	}
+	return "", "", fmt.Errorf("unknown winner %q", verdict.Winner)
+}
+
+// judgeContext numbers retrieved chunks for a judge prompt, cut to maxRunes.
+func judgeContext(chunks []*types.SearchResult, maxRunes int) string {
+	var sb strings.Builder
+	for i, chunk := range chunks {
+		if chunk != nil {
+			fmt.Fprintf(&sb, "[%d] %s\n", i+1, chunk.Content)
+		}
+	}
+	retrieved := []rune(sb.String())
+	if len(retrieved) > maxRunes {
+		retrieved = retrieved[:maxRunes]
+	}
+	return string(retrieved)
+}
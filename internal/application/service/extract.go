@@ -82,7 +82,7 @@ Please output in the following format (one paragraph per column):
 
 // NewChunkExtractTask creates a new chunk extract task. It returns
 // (enqueued, err): enqueued is true only when a task was actually placed on
-// the queue. When NEO4J is disabled the call is a no-op and returns
+// the queue. When no graph store is enabled the call is a no-op and returns
 // (false, nil) — callers that seeded a pending-subtask counter for this chunk
 // MUST release that slot, otherwise the parent knowledge stays stuck in
 // "finalizing" forever (the graph subtask it's waiting on was never enqueued).
@@ -96,8 +96,8 @@ func NewChunkExtractTask(
 	attempt int,
 	chunkIndex int,
 ) (bool, error) {
-	if strings.ToLower(os.Getenv("NEO4J_ENABLE")) != "true" {
-		logger.Warn(ctx, "NEO4J is not enabled, skip chunk extract task")
+	if !types.GraphStoreEnabled() {
+		logger.Warn(ctx, "Graph store is not enabled, skip chunk extract task")
 		return false, nil
 	}
 	taskPayload := types.ExtractChunkPayload{
@@ -162,6 +162,7 @@ type ChunkExtractService struct {
 	knowledgeRepo     interfaces.KnowledgeRepository
 	chunkRepo         interfaces.ChunkRepository
 	graphEngine       interfaces.RetrieveGraphRepository
+	task              interfaces.TaskEnqueuer
 	// spanTracker records this graph-extract task's subspan under the
 	// parent attempt's postprocess stage so the trace viewer shows real
 	// per-chunk graph extraction time rather than the upstream's enqueue.
@@ -176,6 +177,7 @@ func NewChunkExtractService(
 	knowledgeRepo interfaces.KnowledgeRepository,
 	chunkRepo interfaces.ChunkRepository,
 	graphEngine interfaces.RetrieveGraphRepository,
+	task interfaces.TaskEnqueuer,
 	spanTracker SpanTracker,
 ) interfaces.TaskHandler {
 	return &ChunkExtractService{
@@ -185,6 +187,7 @@ func NewChunkExtractService(
 		knowledgeRepo:     knowledgeRepo,
 		chunkRepo:         chunkRepo,
 		graphEngine:       graphEngine,
+		task:              task,
 		spanTracker:       spanTracker,
 	}
 }
@@ -352,6 +355,11 @@ func (s *ChunkExtractService) Handle(ctx context.Context, t *asynq.Task) error {
 		return err
 	}
 	graphOut["nodes_added"] = len(graph.Node)
+	if kb.SummaryModelID != "" && len(graph.Relation) > 0 {
+		// Community reports go stale as the graph grows; rebuild them once
+		// this batch of chunks has settled.
+		ScheduleGraphCommunityBuild(ctx, s.task, p.TenantID, chunk.KnowledgeBaseID)
+	}
 	graphOut["relations_added"] = len(graph.Relation)
 	// Capture a couple of sample nodes/relations so the trace viewer can
 	// answer "what did the LLM actually extract?" without round-tripping
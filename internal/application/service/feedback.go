@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// defaultFeedbackStatsDays is the date range of stats and exports
+	// requested without one
+	defaultFeedbackStatsDays = 30
+	// maxFeedbackStatsDays bounds the date range of stats and exports
+	maxFeedbackStatsDays = 366
+	// feedbackTopWrongSources is how many wrong sources the stats list
+	feedbackTopWrongSources = 20
+	// feedbackExportPageSize is how many feedback an export loads at once
+	feedbackExportPageSize = 500
+	// maxFeedbackExportRows bounds the feedback of one export
+	maxFeedbackExportRows = 50000
+)
+
+// feedbackService records thumbs up/down and wrong-source feedback on
+// answers. Each feedback snapshots the question, the answer and the
+// retrieved sources, so the aggregates show which knowledge bases, chunks
+// and score ranges produce bad answers, and the export turns the feedback
+// into evaluation datasets and reranker training data.
+type feedbackService struct {
+	repo           interfaces.FeedbackRepository
+	messageService interfaces.MessageService
+	messageRepo    interfaces.MessageRepository
+	now            func() time.Time
+}
+
+// NewFeedbackService creates the message feedback service.
+func NewFeedbackService(
+	repo interfaces.FeedbackRepository,
+	messageService interfaces.MessageService,
+	messageRepo interfaces.MessageRepository,
+) interfaces.FeedbackService {
+	return &feedbackService{
+		repo:           repo,
+		messageService: messageService,
+		messageRepo:    messageRepo,
+		now:            time.Now,
+	}
+}
+
+// getAnswer returns an assistant message of a session the caller can see
+func (s *feedbackService) getAnswer(ctx context.Context, sessionID, messageID string) (*types.Message, error) {
+	message, err := s.messageService.GetMessage(ctx, sessionID, messageID)
+	if errors.Is(err, werrors.ErrSessionNotFound) {
+		return nil, werrors.NewNotFoundError("会话不存在")
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && message == nil) {
+		return nil, werrors.NewNotFoundError("消息不存在")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if message.Role != "assistant" {
+		return nil, werrors.NewBadRequestError("只能对回答进行反馈")
+	}
+	return message, nil
+}
+
+// SubmitFeedback creates or replaces the caller's feedback on an assistant message
+func (s *feedbackService) SubmitFeedback(
+	ctx context.Context, sessionID, messageID string, req *types.MessageFeedbackRequest,
+) (*types.MessageFeedback, error) {
+	if err := req.Validate(); err != nil {
+		return nil, werrors.NewBadRequestError("反馈参数不合法").WithDetails(err.Error())
+	}
+	message, err := s.getAnswer(ctx, sessionID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	retrieved := make(map[string]bool, len(message.KnowledgeReferences))
+	for _, ref := range message.KnowledgeReferences {
+		if ref != nil {
+			retrieved[ref.ID] = true
+		}
+	}
+	for _, id := range req.WrongChunkIDs {
+		if !retrieved[id] {
+			return nil, werrors.NewBadRequestError("该分块不是此回答的引用来源").WithDetails(id)
+		}
+	}
+
+	question := ""
+	if message.RequestID != "" {
+		userMessage, err := s.messageRepo.GetUserMessageByRequestID(ctx, sessionID, message.RequestID)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to get the question of message %s: %v", messageID, err)
+		} else {
+			question = userMessage.Content
+		}
+	}
+
+	userID, _ := types.UserIDFromContext(ctx)
+	feedback := &types.MessageFeedback{
+		ID:           uuid.New().String(),
+		TenantID:     types.MustTenantIDFromContext(ctx),
+		SessionID:    sessionID,
+		MessageID:    messageID,
+		UserID:       userID,
+		Rating:       req.Rating,
+		Comment:      req.Comment,
+		WrongSources: len(req.WrongChunkIDs),
+		Day:          s.now().UTC().Format(types.TokenUsageDayLayout),
+		Question:     question,
+		Answer:       message.Content,
+		Sources:      types.NewFeedbackSources(message.KnowledgeReferences, message.Citations, req.WrongChunkIDs),
+	}
+	if err := s.repo.SaveFeedback(ctx, feedback); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Feedback on message %s saved, rating: %q, wrong sources: %d",
+		messageID, feedback.Rating, feedback.WrongSources)
+	return feedback, nil
+}
+
+// GetFeedback returns the caller's feedback on a message, or nil
+func (s *feedbackService) GetFeedback(
+	ctx context.Context, sessionID, messageID string,
+) (*types.MessageFeedback, error) {
+	if _, err := s.getAnswer(ctx, sessionID, messageID); err != nil {
+		return nil, err
+	}
+	userID, _ := types.UserIDFromContext(ctx)
+	return s.repo.GetFeedback(ctx, types.MustTenantIDFromContext(ctx), messageID, userID)
+}
+
+// DeleteFeedback withdraws the caller's feedback on a message
+func (s *feedbackService) DeleteFeedback(ctx context.Context, sessionID, messageID string) error {
+	if _, err := s.getAnswer(ctx, sessionID, messageID); err != nil {
+		return err
+	}
+	userID, _ := types.UserIDFromContext(ctx)
+	return s.repo.DeleteFeedback(ctx, types.MustTenantIDFromContext(ctx), messageID, userID)
+}
+
+// resolveQuery scopes a query to the caller's tenant and fills in its date
+// range, which defaults to the last 30 days.
+func (s *feedbackService) resolveQuery(ctx context.Context, query *types.FeedbackQuery) (*types.FeedbackQuery, error) {
+	q := *query
+	q.TenantID = types.MustTenantIDFromContext(ctx)
+	switch q.Rating {
+	case "", types.FeedbackRatingUp, types.FeedbackRatingDown:
+	default:
+		return nil, werrors.NewBadRequestError(fmt.Sprintf("不支持的评价：%s", q.Rating))
+	}
+	var err error
+	q.StartDay, q.EndDay, err = resolveDayRange(s.now(), q.StartDay, q.EndDay, defaultFeedbackStatsDays, maxFeedbackStatsDays)
+	if err != nil {
+		return nil, err
+	}
+	return &q, nil
+}
+
+// resolveDayRange parses an inclusive UTC date range of stats. An unset end
+// is today and an unset start is defaultDays before the end; the range may
+// span at most maxDays.
+func resolveDayRange(now time.Time, startDay, endDay string, defaultDays, maxDays int) (string, string, error) {
+	end := now.UTC()
+	if endDay != "" {
+		parsed, err := time.Parse(types.TokenUsageDayLayout, endDay)
+		if err != nil {
+			return "", "", werrors.NewBadRequestError("end_date 格式应为 YYYY-MM-DD")
+		}
+		end = parsed
+	}
+	start := end.AddDate(0, 0, 1-defaultDays)
+	if startDay != "" {
+		parsed, err := time.Parse(types.TokenUsageDayLayout, startDay)
+		if err != nil {
+			return "", "", werrors.NewBadRequestError("start_date 格式应为 YYYY-MM-DD")
+		}
+		start = parsed
+	}
+	startDay = start.Format(types.TokenUsageDayLayout)
+	endDay = end.Format(types.TokenUsageDayLayout)
+	if startDay > endDay {
+		return "", "", werrors.NewBadRequestError("start_date 不能晚于 end_date")
+	}
+	if end.Sub(start) >= time.Duration(maxDays)*24*time.Hour {
+		return "", "", werrors.NewBadRequestError(fmt.Sprintf("日期范围不能超过 %d 天", maxDays))
+	}
+	return startDay, endDay, nil
+}
+
+// GetStats aggregates the feedback of the caller's tenant
+func (s *feedbackService) GetStats(ctx context.Context, query *types.FeedbackQuery) (*types.FeedbackStats, error) {
+	q, err := s.resolveQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	stats := &types.FeedbackStats{StartDate: q.StartDay, EndDate: q.EndDay}
+
+	if stats.Days, err = s.repo.CountByDay(ctx, q); err != nil {
+		return nil, err
+	}
+	for _, day := range stats.Days {
+		stats.Total.Add(day.FeedbackCounts)
+	}
+	if stats.KnowledgeBases, err = s.repo.CountSourcesByKnowledgeBase(ctx, q); err != nil {
+		return nil, err
+	}
+	if stats.TopWrongSources, err = s.repo.TopWrongSources(ctx, q, feedbackTopWrongSources); err != nil {
+		return nil, err
+	}
+	buckets, err := s.repo.CountSourcesByScoreBucket(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	const width = 1.0 / types.FeedbackScoreBuckets
+	for i := 0; i < types.FeedbackScoreBuckets; i++ {
+		stats.ScoreBuckets = append(stats.ScoreBuckets, &types.FeedbackScoreBucketCounts{
+			MinScore:             float64(i) * width,
+			MaxScore:             float64(i+1) * width,
+			FeedbackSourceCounts: buckets[i],
+		})
+	}
+	return stats, nil
+}
+
+// Export returns the feedback of the caller's tenant as JSON lines: the
+// feedback itself, evaluation dataset items or reranker training samples.
+func (s *feedbackService) Export(
+	ctx context.Context, query *types.FeedbackQuery, format types.FeedbackExportFormat,
+) ([]byte, error) {
+	if format == "" {
+		format = types.FeedbackExportRaw
+	}
+	switch format {
+	case types.FeedbackExportRaw, types.FeedbackExportEval, types.FeedbackExportRerank:
+	default:
+		return nil, werrors.NewBadRequestError(fmt.Sprintf("不支持的导出格式：%s", format))
+	}
+	q, err := s.resolveQuery(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []byte
+	afterID, rows := "", 0
+	for rows < maxFeedbackExportRows {
+		page, err := s.repo.ListFeedback(ctx, q, afterID, feedbackExportPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, feedback := range page {
+			line := feedbackExportLine(feedback, format)
+			if line == nil {
+				continue
+			}
+			b, err := json.Marshal(line)
+			if err != nil {
+				return nil, err
+			}
+			out = append(append(out, b...), '\n')
+		}
+		rows += len(page)
+		if len(page) < feedbackExportPageSize {
+			break
+		}
+		afterID = page[len(page)-1].ID
+	}
+	if rows >= maxFeedbackExportRows {
+		logger.Warnf(ctx, "Feedback export truncated at %d rows", maxFeedbackExportRows)
+	}
+	return out, nil
+}
+
+// feedbackExportLine returns what a feedback exports as in a format, or nil
+// when it has nothing to contribute
+func feedbackExportLine(feedback *types.MessageFeedback, format types.FeedbackExportFormat) interface{} {
+	switch format {
+	case types.FeedbackExportEval:
+		if item := feedback.EvalItem(); item != nil {
+			return item
+		}
+		return nil
+	case types.FeedbackExportRerank:
+		if sample := feedback.RerankSample(); sample != nil {
+			return sample
+		}
+		return nil
+	}
+	return feedback
+}
@@ -28,6 +28,7 @@ type cosFileService struct {
 	tempBucketURL string
 	bucketName    string
 	region        string
+	urls          urlOptions
 }
 
 const cosScheme = "cos://"
@@ -47,7 +48,7 @@ func newCosClient(bucketName, region, secretID, secretKey string) (*cosFileServi
 			SecretKey: secretKey,
 		},
 	})
-	return &cosFileService{client: client, bucketURL: bucketURL, bucketName: bucketName, region: region}, nil
+	return &cosFileService{client: client, bucketURL: bucketURL, bucketName: bucketName, region: region, urls: loadURLOptions()}, nil
 }
 
 // NewCosFileService creates a new COS file service instance
@@ -137,6 +138,28 @@ func (s *cosFileService) GetFile(ctx context.Context, filePathUrl string) (io.Re
 	return resp.Body, nil
 }
 
+// GetFileRange retrieves length bytes of a file from COS starting at offset
+func (s *cosFileService) GetFileRange(ctx context.Context,
+	filePathUrl string, offset, length int64,
+) (io.ReadCloser, int64, error) {
+	objectName, err := s.parseCosObjectName(filePathUrl)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := utils.SafeObjectKey(objectName); err != nil {
+		return nil, 0, fmt.Errorf("invalid file path: %w", err)
+	}
+	byteRange, err := rangeHeader(offset, length)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp, err := s.client.Object.Get(ctx, objectName, &cos.ObjectGetOptions{Range: byteRange})
+	if err != nil {
+		return nil, 0, wrapRangeError("COS", err)
+	}
+	return resp.Body, contentRangeSize(resp.Header.Get("Content-Range")), nil
+}
+
 // DeleteFile removes a file from COS storage
 func (s *cosFileService) DeleteFile(ctx context.Context, filePath string) error {
 	objectName, err := s.parseCosObjectName(filePath)
@@ -236,20 +259,59 @@ func (s *cosFileService) SaveBytes(ctx context.Context, data []byte, tenantID ui
 	return fmt.Sprintf("cos://%s/%s/%s", s.bucketName, s.region, objectName), nil
 }
 
+// SaveDerivedFile stores data next to the object at filePath, in the temp
+// bucket when the original lives there.
+func (s *cosFileService) SaveDerivedFile(ctx context.Context, filePath, suffix string, data []byte) (string, error) {
+	client := s.client
+	var objectName string
+	if s.tempClient != nil && strings.HasPrefix(filePath, s.tempBucketURL) {
+		client = s.tempClient
+		objectName = strings.TrimPrefix(filePath, s.tempBucketURL)
+	} else {
+		var err error
+		if objectName, err = s.parseCosObjectName(filePath); err != nil {
+			return "", err
+		}
+	}
+	if err := utils.SafeObjectKey(objectName); err != nil {
+		return "", fmt.Errorf("invalid file path: %w", err)
+	}
+	key, err := derivedObjectKey(objectName, suffix)
+	if err != nil {
+		return "", err
+	}
+	_, err = client.Object.Put(ctx, key, bytes.NewReader(data), &cos.ObjectPutOptions{
+		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
+			ContentType: utils.GetContentTypeByExt(filepath.Ext(suffix)),
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload derived file to COS: %w", err)
+	}
+	return filePath + suffix, nil
+}
+
 // GetFileURL returns a presigned download URL for the file
 func (s *cosFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
+	return s.GetFileURLWithExpiry(ctx, filePath, 0)
+}
+
+// GetFileURLWithExpiry returns a presigned download URL valid for expiry,
+// or for the configured default when expiry <= 0.
+func (s *cosFileService) GetFileURLWithExpiry(ctx context.Context, filePath string, expiry time.Duration) (string, error) {
+	ttl := s.urls.expiryFor(expiry, defaultURLExpiry)
 	// 判断文件属于哪个桶
 	if s.tempClient != nil && strings.HasPrefix(filePath, s.tempBucketURL) {
 		objectName := strings.TrimPrefix(filePath, s.tempBucketURL)
 		if err := utils.SafeObjectKey(objectName); err != nil {
 			return "", fmt.Errorf("invalid file path: %w", err)
 		}
-		// Generate presigned URL (valid for 24 hours)
-		presignedURL, err := s.tempClient.Object.GetPresignedURL(ctx, http.MethodGet, objectName, s.tempClient.GetCredential().SecretID, s.tempClient.GetCredential().SecretKey, 24*time.Hour, nil)
+		// Generate presigned URL
+		presignedURL, err := s.tempClient.Object.GetPresignedURL(ctx, http.MethodGet, objectName, s.tempClient.GetCredential().SecretID, s.tempClient.GetCredential().SecretKey, ttl, nil)
 		if err != nil {
 			return "", fmt.Errorf("failed to generate presigned URL for temp bucket: %w", err)
 		}
-		return presignedURL.String(), nil
+		return s.urls.rewrite(presignedURL.String()), nil
 	}
 
 	objectName, err := s.parseCosObjectName(filePath)
@@ -259,11 +321,15 @@ func (s *cosFileService) GetFileURL(ctx context.Context, filePath string) (strin
 	if err := utils.SafeObjectKey(objectName); err != nil {
 		return "", fmt.Errorf("invalid file path: %w", err)
 	}
-	// Generate presigned URL (valid for 24 hours)
-	presignedURL, err := s.client.Object.GetPresignedURL(ctx, http.MethodGet, objectName, s.client.GetCredential().SecretID, s.client.GetCredential().SecretKey, 24*time.Hour, nil)
+	// Generate presigned URL
+	presignedURL, err := s.client.Object.GetPresignedURL(ctx, http.MethodGet, objectName, s.client.GetCredential().SecretID, s.client.GetCredential().SecretKey, ttl, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
 	}
 
-	return presignedURL.String(), nil
+	return s.urls.rewrite(presignedURL.String()), nil
+}
+
+func (s *cosFileService) setURLOptions(o urlOptions) {
+	s.urls = s.urls.merge(o)
 }
@@ -38,6 +38,13 @@ func (s *DummyFileService) GetFile(ctx context.Context, filePath string) (io.Rea
 	return nil, errors.New("not implemented")
 }
 
+// GetFileRange always returns an error as dummy service doesn't store files
+func (s *DummyFileService) GetFileRange(ctx context.Context,
+	filePath string, offset, length int64,
+) (io.ReadCloser, int64, error) {
+	return nil, 0, errors.New("not implemented")
+}
+
 // DeleteFile is a no-op operation that always succeeds
 func (s *DummyFileService) DeleteFile(ctx context.Context, filePath string) error {
 	return nil
@@ -0,0 +1,60 @@
+package file
+
+import (
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// serverSideEncryption is the encryption a file service requests for every
+// object it writes. The zero value leaves objects to the bucket defaults.
+//
+// SSE-S3 and SSE-KMS are transparent to readers: the storage service
+// decrypts on GetObject without any request headers, so only writes (put,
+// multipart create and copy) carry the encryption settings.
+type serverSideEncryption struct {
+	mode     string
+	kmsKeyID string
+}
+
+// enabled reports whether objects are encrypted server-side.
+func (e serverSideEncryption) enabled() bool {
+	return e.mode != types.StorageEncryptionNone
+}
+
+// kms reports whether objects are encrypted with a KMS key.
+func (e serverSideEncryption) kms() bool {
+	return e.mode == types.StorageEncryptionSSEKMS
+}
+
+// keyID mirrors types.StorageEncryptionConfig.KeyID.
+func (e serverSideEncryption) keyID() string {
+	cfg := types.StorageEncryptionConfig{Mode: e.mode, KMSKeyID: e.kmsKeyID}
+	return cfg.KeyID()
+}
+
+// encryptionConfigurable is implemented by file services that can encrypt
+// the objects they write.
+type encryptionConfigurable interface {
+	interfaces.EncryptedFileService
+	setEncryption(sse serverSideEncryption)
+}
+
+// applyEncryption configures svc with cfg. It fails when encryption is
+// requested from a provider that cannot honor it, rather than silently
+// storing files in plaintext.
+func applyEncryption(svc interfaces.FileService, provider string, cfg *types.StorageEncryptionConfig) error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	configurable, ok := svc.(encryptionConfigurable)
+	if !ok {
+		return fmt.Errorf("storage provider %q does not support server-side encryption", provider)
+	}
+	configurable.setEncryption(serverSideEncryption{mode: cfg.Mode, kmsKeyID: cfg.KMSKeyID})
+	return nil
+}
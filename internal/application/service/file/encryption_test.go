@@ -0,0 +1,28 @@
+package file
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestApplyEncryption(t *testing.T) {
+	svc := &s3FileService{}
+	cfg := &types.StorageEncryptionConfig{Mode: types.StorageEncryptionSSEKMS, KMSKeyID: "arn:aws:kms:key/1"}
+	if err := applyEncryption(svc, "s3", cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := svc.EncryptionKeyID(); got != "arn:aws:kms:key/1" {
+		t.Fatalf("EncryptionKeyID = %q, want the KMS key", got)
+	}
+
+	if err := applyEncryption(&localFileService{}, "local", cfg); err == nil {
+		t.Fatal("expected local storage to reject encryption")
+	}
+	if err := applyEncryption(&localFileService{}, "local", nil); err != nil {
+		t.Fatalf("no encryption config must be accepted by every provider: %v", err)
+	}
+	if err := applyEncryption(svc, "s3", &types.StorageEncryptionConfig{Mode: types.StorageEncryptionSSEKMS}); err == nil {
+		t.Fatal("expected sse-kms without a key to be rejected")
+	}
+}
@@ -14,10 +14,30 @@ import (
 // NewFileServiceFromStorageConfig builds a provider-specific FileService from tenant storage config.
 // provider can be empty; in that case it falls back to sec.DefaultProvider.
 // Returns the resolved provider name together with the service.
+// When sec.Encryption is set the service encrypts every object it writes;
+// sec.FileURL overrides the expiry and host of the download URLs it returns.
 func NewFileServiceFromStorageConfig(
 	provider string,
 	sec *types.StorageEngineConfig,
 	localBaseDir string,
+) (interfaces.FileService, string, error) {
+	svc, p, err := newProviderFileService(provider, sec, localBaseDir)
+	if err != nil || sec == nil {
+		return svc, p, err
+	}
+	if err := applyEncryption(svc, p, sec.Encryption); err != nil {
+		return nil, p, err
+	}
+	applyURLConfig(svc, sec.FileURL)
+	return WithTracing(svc, p), p, nil
+}
+
+// newProviderFileService builds the FileService for provider without
+// applying tenant-wide settings such as encryption.
+func newProviderFileService(
+	provider string,
+	sec *types.StorageEngineConfig,
+	localBaseDir string,
 ) (interfaces.FileService, string, error) {
 	p := strings.ToLower(strings.TrimSpace(provider))
 	if p == "" && sec != nil {
@@ -99,7 +119,21 @@ func NewFileServiceFromStorageConfig(
 		if pathPrefix == "" {
 			pathPrefix = "weknora/"
 		}
-		svc, err := NewS3FileService(sec.S3.Endpoint, sec.S3.AccessKey, sec.S3.SecretKey, sec.S3.BucketName, sec.S3.Region, pathPrefix)
+		svc, err := NewS3FileService(sec.S3.Endpoint, sec.S3.AccessKey, sec.S3.SecretKey, sec.S3.BucketName, sec.S3.Region, pathPrefix, sec.S3.ForcePathStyle)
+		return svc, p, err
+
+	case "gcs":
+		// Tenant configs must carry their own key: falling back to the
+		// server's workload identity would lend tenants its bucket access.
+		if sec == nil || sec.GCS == nil || sec.GCS.BucketName == "" || sec.GCS.CredentialsJSON == "" {
+			return nil, p, fmt.Errorf("incomplete gcs config")
+		}
+		pathPrefix := strings.TrimSpace(sec.GCS.PathPrefix)
+		if pathPrefix == "" {
+			pathPrefix = "weknora/"
+		}
+		svc, err := NewGCSFileService(sec.GCS.BucketName, sec.GCS.ProjectID, sec.GCS.Location,
+			sec.GCS.CredentialsJSON, "", pathPrefix)
 		return svc, p, err
 
 	case "obs":
@@ -0,0 +1,608 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/compute/metadata"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iamcredentials/v1"
+	"google.golang.org/api/option"
+	"google.golang.org/api/storage/v1"
+)
+
+const (
+	// gcsHost serves signed URLs in path style: https://{gcsHost}/{bucket}/{object}.
+	gcsHost = "storage.googleapis.com"
+	// gcsSigningAlgorithm is the V4 signing algorithm for RSA service account keys.
+	gcsSigningAlgorithm = "GOOG4-RSA-SHA256"
+	// gcsUploadChunkSize is the chunk size of resumable uploads; files larger
+	// than one chunk are uploaded in several requests.
+	gcsUploadChunkSize = 16 << 20
+)
+
+// gcsFileService Google Cloud Storage file service implementation
+type gcsFileService struct {
+	service    *storage.Service
+	bucketName string
+	pathPrefix string
+	signer     *gcsURLSigner
+	urls       urlOptions
+}
+
+// gcsCredentials is the subset of a service account key file used here.
+type gcsCredentials struct {
+	Type        string `json:"type"`
+	ProjectID   string `json:"project_id"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+}
+
+// newGCSClient creates a bare gcsFileService with just the API client initialised.
+// With credentialsJSON empty the client uses Application Default Credentials,
+// which covers workload identity on GKE and the metadata server on GCE.
+func newGCSClient(ctx context.Context,
+	bucketName, credentialsJSON, serviceAccountEmail, pathPrefix string,
+) (*gcsFileService, *gcsCredentials, error) {
+	var opts []option.ClientOption
+	var creds *gcsCredentials
+	if credentialsJSON != "" {
+		creds = &gcsCredentials{}
+		if err := json.Unmarshal([]byte(credentialsJSON), creds); err != nil {
+			return nil, nil, fmt.Errorf("invalid GCS credentials JSON: %w", err)
+		}
+		if creds.Type != "service_account" {
+			return nil, nil, fmt.Errorf("unsupported GCS credentials type %q, want service_account", creds.Type)
+		}
+		opts = append(opts, option.WithCredentialsJSON([]byte(credentialsJSON)))
+	}
+	opts = append(opts, option.WithScopes(storage.DevstorageReadWriteScope))
+
+	service, err := storage.NewService(ctx, opts...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create GCS client: %w", err)
+	}
+
+	signer := &gcsURLSigner{email: serviceAccountEmail}
+	if creds != nil {
+		key, err := parseGCSPrivateKey(creds.PrivateKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		signer.email = creds.ClientEmail
+		signer.key = key
+	} else {
+		signer.iam, err = iamcredentials.NewService(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create IAM credentials client: %w", err)
+		}
+	}
+
+	// Normalize pathPrefix: ensure it ends with '/' if not empty
+	pathPrefix = strings.Trim(pathPrefix, "/")
+	if pathPrefix != "" {
+		pathPrefix += "/"
+	}
+
+	return &gcsFileService{
+		service:    service,
+		bucketName: bucketName,
+		pathPrefix: pathPrefix,
+		signer:     signer,
+		urls:       loadURLOptions(),
+	}, creds, nil
+}
+
+// NewGCSFileService creates a Google Cloud Storage file service.
+// It verifies that the bucket exists and creates it in projectID and location
+// if missing. projectID defaults to the one in credentialsJSON.
+func NewGCSFileService(bucketName, projectID, location,
+	credentialsJSON, serviceAccountEmail, pathPrefix string,
+) (interfaces.FileService, error) {
+	ctx := context.Background()
+	svc, creds, err := newGCSClient(ctx, bucketName, credentialsJSON, serviceAccountEmail, pathPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := svc.bucketExists(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket: %w", err)
+	}
+	if !exists {
+		if projectID == "" && creds != nil {
+			projectID = creds.ProjectID
+		}
+		if projectID == "" {
+			return nil, fmt.Errorf("bucket %q does not exist and no project ID is configured to create it", bucketName)
+		}
+		bucket := &storage.Bucket{
+			Name:     bucketName,
+			Location: location,
+			// Expire temp files; see temp.go.
+			Lifecycle: &storage.BucketLifecycle{Rule: []*storage.BucketLifecycleRule{{
+				Action: &storage.BucketLifecycleRuleAction{Type: "Delete"},
+				Condition: &storage.BucketLifecycleRuleCondition{
+					Age:           googleapi.Int64(int64(tempLifecycleDays())),
+					MatchesPrefix: []string{tempKeyPrefix(svc.pathPrefix)},
+				},
+			}}},
+		}
+		if _, err := svc.service.Buckets.Insert(projectID, bucket).Context(ctx).Do(); err != nil {
+			return nil, fmt.Errorf("failed to create bucket: %w", err)
+		}
+	}
+
+	return svc, nil
+}
+
+// CheckGCSConnectivity tests GCS connectivity using the provided credentials.
+// It creates a temporary service instance internally and delegates to CheckConnectivity.
+func CheckGCSConnectivity(ctx context.Context, bucketName, credentialsJSON string) error {
+	svc, _, err := newGCSClient(ctx, bucketName, credentialsJSON, "", "")
+	if err != nil {
+		return err
+	}
+	return svc.CheckConnectivity(ctx)
+}
+
+// bucketExists checks if the bucket exists
+func (s *gcsFileService) bucketExists(ctx context.Context) (bool, error) {
+	_, err := s.service.Buckets.Get(s.bucketName).Context(ctx).Do()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// CheckConnectivity verifies GCS is reachable and that the bucket exists.
+// This is a read-only probe — it never creates a bucket.
+func (s *gcsFileService) CheckConnectivity(ctx context.Context) error {
+	checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	exists, err := s.bucketExists(checkCtx)
+	if err != nil {
+		return fmt.Errorf("GCS connectivity check failed: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("bucket %q does not exist", s.bucketName)
+	}
+	return nil
+}
+
+// parseGCSFilePath extracts the object name from a provider scheme: gcs://{bucket}/{objectKey}
+func (s *gcsFileService) parseGCSFilePath(filePath string) (string, error) {
+	const prefix = "gcs://"
+	if !strings.HasPrefix(filePath, prefix) {
+		return "", fmt.Errorf("invalid GCS file path: %s", filePath)
+	}
+	rest := strings.TrimPrefix(filePath, prefix)
+	parts := strings.SplitN(rest, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid GCS file path: %s", filePath)
+	}
+	if parts[0] != s.bucketName {
+		return "", fmt.Errorf("bucket mismatch in path: got %s, want %s", parts[0], s.bucketName)
+	}
+	if err := utils.SafeObjectKey(parts[1]); err != nil {
+		return "", fmt.Errorf("invalid file path: %w", err)
+	}
+	return parts[1], nil
+}
+
+// putObject uploads body to objectName. Bodies larger than
+// gcsUploadChunkSize go through a resumable upload.
+func (s *gcsFileService) putObject(ctx context.Context, objectName, contentType string, body io.Reader) error {
+	object := &storage.Object{Name: objectName, ContentType: contentType}
+	_, err := s.service.Objects.Insert(s.bucketName, object).
+		Media(body, googleapi.ContentType(contentType), googleapi.ChunkSize(gcsUploadChunkSize)).
+		Context(ctx).Do()
+	return err
+}
+
+// SaveFile saves a file to GCS
+func (s *gcsFileService) SaveFile(ctx context.Context,
+	file *multipart.FileHeader, tenantID uint64, knowledgeID string,
+) (string, error) {
+	ext := filepath.Ext(file.Filename)
+	objectName := fmt.Sprintf("%s%d/%s/%s%s", s.pathPrefix, tenantID, knowledgeID, uuid.New().String(), ext)
+
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer src.Close()
+
+	contentType := file.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = utils.GetContentTypeByExt(ext)
+	}
+
+	if err := s.putObject(ctx, objectName, contentType, src); err != nil {
+		return "", fmt.Errorf("failed to upload file to GCS: %w", err)
+	}
+
+	return fmt.Sprintf("gcs://%s/%s", s.bucketName, objectName), nil
+}
+
+// GetFile gets a file from GCS
+func (s *gcsFileService) GetFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	objectName, err := s.parseGCSFilePath(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.service.Objects.Get(s.bucketName, objectName).Context(ctx).Download()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file from GCS: %w", err)
+	}
+
+	return resp.Body, nil
+}
+
+// GetFileRange gets length bytes of a file from GCS starting at offset
+func (s *gcsFileService) GetFileRange(ctx context.Context,
+	filePath string, offset, length int64,
+) (io.ReadCloser, int64, error) {
+	objectName, err := s.parseGCSFilePath(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	byteRange, err := rangeHeader(offset, length)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	call := s.service.Objects.Get(s.bucketName, objectName).Context(ctx)
+	call.Header().Set("Range", byteRange)
+	resp, err := call.Download()
+	if err != nil {
+		var apiErr *googleapi.Error
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusRequestedRangeNotSatisfiable {
+			return nil, 0, ErrInvalidRange
+		}
+		return nil, 0, fmt.Errorf("failed to get file range from GCS: %w", err)
+	}
+
+	return resp.Body, contentRangeSize(resp.Header.Get("Content-Range")), nil
+}
+
+// DeleteFile deletes a file
+func (s *gcsFileService) DeleteFile(ctx context.Context, filePath string) error {
+	objectName, err := s.parseGCSFilePath(filePath)
+	if err != nil {
+		return err
+	}
+
+	if err := s.service.Objects.Delete(s.bucketName, objectName).Context(ctx).Do(); err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+
+	return nil
+}
+
+// CopyFile copies an existing GCS object to a new knowledge-owned object using
+// server-side rewrites (no data leaves GCS). The destination uses the same
+// layout as SaveFile. Returns ErrCrossBackendCopy when srcPath is not a gcs:// path.
+func (s *gcsFileService) CopyFile(ctx context.Context,
+	srcPath string, tenantID uint64, knowledgeID string,
+) (string, error) {
+	srcKey, err := s.parseGCSFilePath(srcPath)
+	if err != nil {
+		return "", fmt.Errorf("gcs copy rejected source %q: %w", srcPath, ErrCrossBackendCopy)
+	}
+
+	ext := filepath.Ext(srcPath)
+	destKey := fmt.Sprintf("%s%d/%s/%s%s", s.pathPrefix, tenantID, knowledgeID, uuid.New().String(), ext)
+
+	// Large objects may take several rewrite calls; each returns a token to
+	// continue from until Done is set.
+	call := s.service.Objects.Rewrite(s.bucketName, srcKey, s.bucketName, destKey, &storage.Object{})
+	for {
+		resp, err := call.Context(ctx).Do()
+		if err != nil {
+			return "", fmt.Errorf("failed to copy file in GCS: %w", err)
+		}
+		if resp.Done {
+			break
+		}
+		call = call.RewriteToken(resp.RewriteToken)
+	}
+
+	newPath := fmt.Sprintf("gcs://%s/%s", s.bucketName, destKey)
+	logger.Infof(ctx, "Copied GCS object %s to %s", srcPath, newPath)
+	return newPath, nil
+}
+
+// SaveBytes saves bytes data to GCS and returns the file path
+// Temp files go under the temp/ prefix, where they expire; see temp.go.
+func (s *gcsFileService) SaveBytes(ctx context.Context, data []byte, tenantID uint64, fileName string, temp bool) (string, error) {
+	safeName, err := utils.SafeFileName(fileName)
+	if err != nil {
+		return "", fmt.Errorf("invalid file name: %w", err)
+	}
+	ext := filepath.Ext(safeName)
+	objectName := fmt.Sprintf("%s%d/exports/%s%s", s.pathPrefix, tenantID, uuid.New().String(), ext)
+	if temp {
+		objectName = tempObjectKey(s.pathPrefix, tenantID, ext)
+	}
+
+	if err := s.putObject(ctx, objectName, utils.GetContentTypeByExt(ext), bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("failed to upload bytes to GCS: %w", err)
+	}
+
+	return fmt.Sprintf("gcs://%s/%s", s.bucketName, objectName), nil
+}
+
+// SaveDerivedFile stores data next to the object at filePath.
+func (s *gcsFileService) SaveDerivedFile(ctx context.Context, filePath, suffix string, data []byte) (string, error) {
+	objectName, err := s.parseGCSFilePath(filePath)
+	if err != nil {
+		return "", err
+	}
+	key, err := derivedObjectKey(objectName, suffix)
+	if err != nil {
+		return "", err
+	}
+	if err := s.putObject(ctx, key, utils.GetContentTypeByExt(filepath.Ext(suffix)), bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("failed to upload derived file to GCS: %w", err)
+	}
+	return filePath + suffix, nil
+}
+
+// GetFileURL returns a signed download URL for the file
+func (s *gcsFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
+	return s.GetFileURLWithExpiry(ctx, filePath, 0)
+}
+
+// GetFileURLWithExpiry returns a presigned download URL valid for expiry,
+// or for the configured default when expiry <= 0.
+func (s *gcsFileService) GetFileURLWithExpiry(ctx context.Context, filePath string, expiry time.Duration) (string, error) {
+	ttl := s.urls.expiryFor(expiry, defaultURLExpiry)
+	objectName, err := s.parseGCSFilePath(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	signedURL, err := s.signer.signedURL(ctx, http.MethodGet, s.bucketName, objectName, "", time.Now(), ttl)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate signed URL: %w", err)
+	}
+	return s.urls.rewrite(signedURL), nil
+}
+
+func (s *gcsFileService) setURLOptions(o urlOptions) {
+	s.urls = s.urls.merge(o)
+}
+
+// GetUploadURL returns a signed PUT URL for the object at filePath, valid for
+// presignUploadExpiry; see interfaces.PresignedUploader.
+func (s *gcsFileService) GetUploadURL(ctx context.Context, filePath, contentType string) (string, error) {
+	objectName, err := s.parseGCSFilePath(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	signedURL, err := s.signer.signedURL(ctx, http.MethodPut, s.bucketName, objectName, contentType,
+		time.Now(), presignUploadExpiry)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate signed upload URL: %w", err)
+	}
+	return signedURL, nil
+}
+
+// gcsURLSigner produces V4 signed URLs. It signs locally with the service
+// account key when one is configured, and otherwise through the IAM
+// Credentials signBlob API as the workload's service account, which needs
+// roles/iam.serviceAccountTokenCreator on that account.
+type gcsURLSigner struct {
+	key *rsa.PrivateKey
+	iam *iamcredentials.Service
+
+	mu    sync.Mutex
+	email string
+}
+
+// parseGCSPrivateKey decodes the PEM private key of a service account key file.
+func parseGCSPrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, fmt.Errorf("invalid GCS service account private key")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GCS service account private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GCS service account private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// signerEmail returns the service account the URLs are signed as, asking the
+// metadata server for the workload's default account when none is configured.
+func (g *gcsURLSigner) signerEmail(ctx context.Context) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.email != "" {
+		return g.email, nil
+	}
+	email, err := metadata.EmailWithContext(ctx, "default")
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve service account email for signing: %w", err)
+	}
+	g.email = email
+	return email, nil
+}
+
+// sign returns the RSA-SHA256 signature of payload.
+func (g *gcsURLSigner) sign(ctx context.Context, email string, payload []byte) ([]byte, error) {
+	if g.key != nil {
+		digest := sha256.Sum256(payload)
+		return rsa.SignPKCS1v15(rand.Reader, g.key, crypto.SHA256, digest[:])
+	}
+	resp, err := g.iam.Projects.ServiceAccounts.SignBlob("projects/-/serviceAccounts/"+email,
+		&iamcredentials.SignBlobRequest{Payload: base64.StdEncoding.EncodeToString(payload)}).
+		Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("signBlob failed: %w", err)
+	}
+	return base64.StdEncoding.DecodeString(resp.SignedBlob)
+}
+
+// signedURL builds a V4 signed URL for method on bucket/object. When
+// contentType is set it is a signed header, so the request must send it
+// unchanged.
+func (g *gcsURLSigner) signedURL(ctx context.Context,
+	method, bucket, object, contentType string, now time.Time, expires time.Duration,
+) (string, error) {
+	email, err := g.signerEmail(ctx)
+	if err != nil {
+		return "", err
+	}
+	_, stringToSign, query := gcsCanonicalRequest(method, bucket, object, contentType, email, now, expires)
+	signature, err := g.sign(ctx, email, []byte(stringToSign))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("https://%s%s?%s&X-Goog-Signature=%s",
+		gcsHost, gcsResourcePath(bucket, object), query, hex.EncodeToString(signature)), nil
+}
+
+// gcsCanonicalRequest builds the V4 canonical request for a signed URL and
+// returns it together with the string to sign and the canonical query string.
+func gcsCanonicalRequest(method, bucket, object, contentType, email string,
+	now time.Time, expires time.Duration,
+) (canonicalRequest, stringToSign, query string) {
+	now = now.UTC()
+	datestamp := now.Format("20060102")
+	timestamp := now.Format("20060102T150405Z")
+	scope := datestamp + "/auto/storage/goog4_request"
+
+	headers := map[string]string{"host": gcsHost}
+	if contentType != "" {
+		headers["content-type"] = contentType
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	params := map[string]string{
+		"X-Goog-Algorithm":     gcsSigningAlgorithm,
+		"X-Goog-Credential":    email + "/" + scope,
+		"X-Goog-Date":          timestamp,
+		"X-Goog-Expires":       strconv.FormatInt(int64(expires/time.Second), 10),
+		"X-Goog-SignedHeaders": signedHeaders,
+	}
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, gcsEscape(k, false)+"="+gcsEscape(params[k], false))
+	}
+	query = strings.Join(pairs, "&")
+
+	canonicalRequest = strings.Join([]string{
+		method,
+		gcsResourcePath(bucket, object),
+		query,
+		canonicalHeaders.String(),
+		signedHeaders,
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	digest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign = strings.Join([]string{
+		gcsSigningAlgorithm,
+		timestamp,
+		scope,
+		hex.EncodeToString(digest[:]),
+	}, "\n")
+	return canonicalRequest, stringToSign, query
+}
+
+// gcsResourcePath returns the escaped path-style resource of an object.
+func gcsResourcePath(bucket, object string) string {
+	return "/" + bucket + "/" + gcsEscape(object, true)
+}
+
+// gcsEscape percent-encodes s per RFC 3986 as V4 signing requires, leaving
+// only unreserved characters (and '/' when keepSlash is set) unescaped.
+func gcsEscape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// CleanupTempFiles deletes tenantID's temp files last modified before
+// olderThan; see interfaces.TempFileCleaner.
+func (s *gcsFileService) CleanupTempFiles(ctx context.Context, tenantID uint64, olderThan time.Time) (int, error) {
+	deleted := 0
+	err := s.service.Objects.List(s.bucketName).Prefix(tempTenantPrefix(s.pathPrefix, tenantID)).
+		Fields("nextPageToken", "items(name,updated)").
+		Pages(ctx, func(page *storage.Objects) error {
+			for _, obj := range page.Items {
+				updated, err := time.Parse(time.RFC3339, obj.Updated)
+				if err != nil || !updated.Before(olderThan) {
+					continue
+				}
+				if err := s.service.Objects.Delete(s.bucketName, obj.Name).Context(ctx).Do(); err != nil {
+					return fmt.Errorf("failed to delete %s: %w", obj.Name, err)
+				}
+				deleted++
+			}
+			return nil
+		})
+	return deleted, err
+}
@@ -0,0 +1,88 @@
+package file
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGCSCanonicalRequest(t *testing.T) {
+	now := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	canonical, stringToSign, query := gcsCanonicalRequest("PUT", "bucket", "weknora/1/kb/a b+c.pdf",
+		"application/pdf", "svc@project.iam.gserviceaccount.com", now, time.Hour)
+
+	wantQuery := "X-Goog-Algorithm=GOOG4-RSA-SHA256" +
+		"&X-Goog-Credential=svc%40project.iam.gserviceaccount.com%2F20260304%2Fauto%2Fstorage%2Fgoog4_request" +
+		"&X-Goog-Date=20260304T050607Z&X-Goog-Expires=3600&X-Goog-SignedHeaders=content-type%3Bhost"
+	if query != wantQuery {
+		t.Fatalf("query = %s\nwant    %s", query, wantQuery)
+	}
+	wantCanonical := "PUT\n/bucket/weknora/1/kb/a%20b%2Bc.pdf\n" + wantQuery +
+		"\ncontent-type:application/pdf\nhost:storage.googleapis.com\n\ncontent-type;host\nUNSIGNED-PAYLOAD"
+	if canonical != wantCanonical {
+		t.Fatalf("canonical request = %q\nwant %q", canonical, wantCanonical)
+	}
+	digest := sha256.Sum256([]byte(canonical))
+	wantStringToSign := "GOOG4-RSA-SHA256\n20260304T050607Z\n20260304/auto/storage/goog4_request\n" +
+		hex.EncodeToString(digest[:])
+	if stringToSign != wantStringToSign {
+		t.Fatalf("string to sign = %q\nwant %q", stringToSign, wantStringToSign)
+	}
+}
+
+func TestGCSSignedURLWithServiceAccountKey(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := &gcsURLSigner{key: key, email: "svc@project.iam.gserviceaccount.com"}
+	now := time.Now()
+
+	signed, err := signer.signedURL(context.Background(), "GET", "bucket", "1/kb/file.pdf", "", now, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Host != gcsHost || u.Path != "/bucket/1/kb/file.pdf" {
+		t.Fatalf("unexpected signed URL %s", signed)
+	}
+	if got := u.Query().Get("X-Goog-SignedHeaders"); got != "host" {
+		t.Fatalf("signed headers = %q, want host", got)
+	}
+
+	_, stringToSign, _ := gcsCanonicalRequest("GET", "bucket", "1/kb/file.pdf", "",
+		signer.email, now, 24*time.Hour)
+	signature, err := hex.DecodeString(u.Query().Get("X-Goog-Signature"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(stringToSign))
+	if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Fatalf("signature does not verify: %v", err)
+	}
+	if strings.Contains(signed, "+") {
+		t.Fatalf("signed URL is not RFC 3986 encoded: %s", signed)
+	}
+}
+
+func TestParseGCSFilePath(t *testing.T) {
+	svc := &gcsFileService{bucketName: "test-bucket"}
+	if got, err := svc.parseGCSFilePath("gcs://test-bucket/weknora/1/kb/a.pdf"); err != nil || got != "weknora/1/kb/a.pdf" {
+		t.Fatalf("parseGCSFilePath = %q, %v", got, err)
+	}
+	for _, bad := range []string{"s3://test-bucket/a.pdf", "gcs://other/a.pdf", "gcs://test-bucket/", "gcs://test-bucket/../a"} {
+		if _, err := svc.parseGCSFilePath(bad); err == nil {
+			t.Errorf("parseGCSFilePath(%q) accepted an invalid path", bad)
+		}
+	}
+}
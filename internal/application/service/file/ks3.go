@@ -28,6 +28,7 @@ type ks3FileService struct {
 	client     *ks3s3.S3
 	bucketName string
 	pathPrefix string
+	urls       urlOptions
 }
 
 // NewKS3FileService creates a KS3 file service and ensures the bucket exists.
@@ -43,6 +44,7 @@ func NewKS3FileService(endpoint, region, accessKey, secretKey, bucketName, pathP
 		client:     client,
 		bucketName: bucketName,
 		pathPrefix: pathPrefix,
+		urls:       loadURLOptions(),
 	}
 
 	if err := ensureKS3Bucket(client, bucketName); err != nil {
@@ -178,6 +180,31 @@ func (s *ks3FileService) SaveBytes(ctx context.Context, data []byte, tenantID ui
 	return fmt.Sprintf("%s%s/%s", ks3Scheme, s.bucketName, objectKey), nil
 }
 
+// SaveDerivedFile stores data next to the object at filePath.
+func (s *ks3FileService) SaveDerivedFile(ctx context.Context, filePath, suffix string, data []byte) (string, error) {
+	_, objectKey, err := parseKS3FilePath(filePath)
+	if err != nil {
+		return "", err
+	}
+	if err := utils.SafeObjectKey(objectKey); err != nil {
+		return "", fmt.Errorf("invalid file path: %w", err)
+	}
+	key, err := derivedObjectKey(objectKey, suffix)
+	if err != nil {
+		return "", err
+	}
+	_, err = s.client.PutObject(&ks3s3.PutObjectInput{
+		Bucket:      ks3aws.String(s.bucketName),
+		Key:         ks3aws.String(key),
+		Body:        bytes.NewReader(data),
+		ContentType: ks3aws.String(utils.GetContentTypeByExt(filepath.Ext(suffix))),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload derived file to KS3: %w", err)
+	}
+	return filePath + suffix, nil
+}
+
 // CopyFile copies an existing KS3 object to a new knowledge-owned object using a
 // server-side CopyObject (no data leaves KS3). The destination uses the same
 // layout as SaveFile. Returns ErrCrossBackendCopy when srcPath is not a ks3:// path.
@@ -230,6 +257,34 @@ func (s *ks3FileService) GetFile(ctx context.Context, filePath string) (io.ReadC
 	return resp.Body, nil
 }
 
+// GetFileRange retrieves length bytes of a file from KS3 starting at offset
+func (s *ks3FileService) GetFileRange(ctx context.Context,
+	filePath string, offset, length int64,
+) (io.ReadCloser, int64, error) {
+	_, objectKey, err := parseKS3FilePath(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := utils.SafeObjectKey(objectKey); err != nil {
+		return nil, 0, fmt.Errorf("invalid file path: %w", err)
+	}
+	byteRange, err := rangeHeader(offset, length)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := s.client.GetObject(&ks3s3.GetObjectInput{
+		Bucket: ks3aws.String(s.bucketName),
+		Key:    ks3aws.String(objectKey),
+		Range:  ks3aws.String(byteRange),
+	})
+	if err != nil {
+		return nil, 0, wrapRangeError("KS3", err)
+	}
+
+	return resp.Body, contentRangeSize(ks3aws.StringValue(resp.ContentRange)), nil
+}
+
 func (s *ks3FileService) DeleteFile(ctx context.Context, filePath string) error {
 	_, objectKey, err := parseKS3FilePath(filePath)
 	if err != nil {
@@ -265,7 +320,15 @@ func (s *ks3FileService) CheckConnectivity(ctx context.Context) error {
 	}
 }
 
+// GetFileURL returns a presigned download URL for the file.
 func (s *ks3FileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
+	return s.GetFileURLWithExpiry(ctx, filePath, 0)
+}
+
+// GetFileURLWithExpiry returns a presigned download URL valid for expiry,
+// or for the configured default when expiry <= 0.
+func (s *ks3FileService) GetFileURLWithExpiry(ctx context.Context, filePath string, expiry time.Duration) (string, error) {
+	ttl := s.urls.expiryFor(expiry, defaultURLExpiry)
 	_, objectKey, err := parseKS3FilePath(filePath)
 	if err != nil {
 		return "", err
@@ -278,11 +341,15 @@ func (s *ks3FileService) GetFileURL(ctx context.Context, filePath string) (strin
 		Bucket:     ks3aws.String(s.bucketName),
 		Key:        ks3aws.String(objectKey),
 		HTTPMethod: ks3s3.HTTPMethod("GET"),
-		Expires:    int64((24 * time.Hour).Seconds()),
+		Expires:    int64(ttl.Seconds()),
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to generate KS3 presigned URL: %w", err)
 	}
 
-	return url, nil
+	return s.urls.rewrite(url), nil
+}
+
+func (s *ks3FileService) setURLOptions(o urlOptions) {
+	s.urls = s.urls.merge(o)
 }
@@ -19,6 +19,7 @@ import (
 type localFileService struct {
 	baseDir     string // Base directory for file storage
 	externalURL string // External URL base for presigned URL generation (empty = return local:// paths)
+	urls        urlOptions
 }
 
 const localScheme = "local://"
@@ -42,6 +43,7 @@ func NewLocalFileService(baseDir, externalURL string) interfaces.FileService {
 	return &localFileService{
 		baseDir:     baseDir,
 		externalURL: strings.TrimRight(externalURL, "/"),
+		urls:        loadURLOptions(),
 	}
 }
 
@@ -128,6 +130,45 @@ func (s *localFileService) GetFile(ctx context.Context, filePath string) (io.Rea
 	return file, nil
 }
 
+// GetFileRange opens a file and returns the section starting at offset.
+// The returned size is that of the whole file.
+func (s *localFileService) GetFileRange(ctx context.Context,
+	filePath string, offset, length int64,
+) (io.ReadCloser, int64, error) {
+	candidate := s.normalizePathForBase(filePath)
+	resolved, err := secutils.SafePathUnderBase(s.baseDir, candidate)
+	if err != nil {
+		logger.Errorf(ctx, "Path traversal denied for GetFileRange: %v", err)
+		return nil, 0, fmt.Errorf("invalid file path: %w", err)
+	}
+	if offset < 0 {
+		return nil, 0, fmt.Errorf("invalid range offset %d", offset)
+	}
+
+	file, err := os.Open(resolved)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("failed to stat file: %w", err)
+	}
+	size := info.Size()
+	if offset >= size {
+		file.Close()
+		return nil, size, ErrInvalidRange
+	}
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("failed to seek file: %w", err)
+	}
+	if length <= 0 || offset+length > size {
+		length = size - offset
+	}
+	return sectionReadCloser{Reader: io.LimitReader(file, length), Closer: file}, size, nil
+}
+
 // DeleteFile removes a file from the local file system
 // Returns an error if deletion fails
 // 路径必须在 baseDir 下，防止路径遍历（如 ../../）
@@ -210,7 +251,7 @@ func (s *localFileService) CopyFile(ctx context.Context,
 }
 
 // SaveBytes saves bytes data to a file and returns the file path
-// temp parameter is ignored for local storage (no auto-expiration support)
+// Temp files go under baseDir/temp/tenantID, where CleanupTempFiles expires them.
 // fileName 仅允许安全文件名，禁止路径遍历（如 ../../）
 func (s *localFileService) SaveBytes(ctx context.Context, data []byte, tenantID uint64, fileName string, temp bool) (string, error) {
 	logger.Infof(ctx, "Saving bytes data: fileName=%s, size=%d, tenantID=%d, temp=%v", fileName, len(data), tenantID, temp)
@@ -223,6 +264,9 @@ func (s *localFileService) SaveBytes(ctx context.Context, data []byte, tenantID
 
 	// Create storage directory with tenant ID
 	dir := filepath.Join(s.baseDir, fmt.Sprintf("%d", tenantID), "exports")
+	if temp {
+		dir = s.tempDir(tenantID)
+	}
 	if err := os.MkdirAll(dir, 0o755); err != nil {
 		logger.Errorf(ctx, "Failed to create directory: %v", err)
 		return "", fmt.Errorf("failed to create directory: %w", err)
@@ -245,10 +289,33 @@ func (s *localFileService) SaveBytes(ctx context.Context, data []byte, tenantID
 	return localScheme + filepath.ToSlash(relPath), nil
 }
 
+// SaveDerivedFile writes data next to the file at filePath.
+func (s *localFileService) SaveDerivedFile(ctx context.Context, filePath, suffix string, data []byte) (string, error) {
+	candidate, err := derivedObjectKey(s.normalizePathForBase(filePath), suffix)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := secutils.SafePathUnderBase(s.baseDir, candidate)
+	if err != nil {
+		logger.Errorf(ctx, "Path traversal denied for SaveDerivedFile: %v", err)
+		return "", fmt.Errorf("invalid file path: %w", err)
+	}
+	if err := os.WriteFile(resolved, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write derived file: %w", err)
+	}
+	return filePath + suffix, nil
+}
+
 // GetFileURL returns a download URL for the file.
 // When externalURL is configured, returns a presigned HTTP URL suitable for external access.
 // Otherwise returns the local://... path for backward compatibility.
 func (s *localFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
+	return s.GetFileURLWithExpiry(ctx, filePath, 0)
+}
+
+// GetFileURLWithExpiry is GetFileURL with presigned URLs valid for expiry,
+// or for the configured default (2h unless set) when expiry <= 0.
+func (s *localFileService) GetFileURLWithExpiry(ctx context.Context, filePath string, expiry time.Duration) (string, error) {
 	// Normalize to provider:// format.
 	normalized := filePath
 	if !strings.HasPrefix(filePath, localScheme) {
@@ -268,17 +335,22 @@ func (s *localFileService) GetFileURL(ctx context.Context, filePath string) (str
 		// tenant's StorageEngineConfig — using the caller's tenant would
 		// break cross-tenant shared resources (e.g. shared KB images).
 		tenantID := secutils.ParseTenantIDFromStoragePath(normalized)
-		presignedURL, err := secutils.SignFileURL(s.externalURL, normalized, tenantID, 0)
+		ttl := s.urls.expiryFor(expiry, 0)
+		presignedURL, err := secutils.SignFileURL(s.externalURL, normalized, tenantID, ttl)
 		if err != nil {
 			logger.Warnf(ctx, "Failed to generate presigned URL for %s: %v, returning local:// path", normalized, err)
 			return normalized, nil
 		}
-		return presignedURL, nil
+		return s.urls.rewrite(presignedURL), nil
 	}
 
 	return normalized, nil
 }
 
+func (s *localFileService) setURLOptions(o urlOptions) {
+	s.urls = s.urls.merge(o)
+}
+
 // normalizePathForBase keeps backward compatibility for legacy file paths:
 // - provider scheme: "local://tenant/.." → baseDir/tenant/..
 // - absolute path: "/data/files/tenant/.."
@@ -308,3 +380,32 @@ func (s *localFileService) normalizePathForBase(filePath string) string {
 	}
 	return filepath.Join(baseClean, cleanNoDot)
 }
+
+// tempDir is the directory of tenantID's temp files.
+func (s *localFileService) tempDir(tenantID uint64) string {
+	return filepath.Join(s.baseDir, tempDirName, fmt.Sprintf("%d", tenantID))
+}
+
+// CleanupTempFiles deletes tenantID's temp files last modified before
+// olderThan; see interfaces.TempFileCleaner.
+func (s *localFileService) CleanupTempFiles(ctx context.Context, tenantID uint64, olderThan time.Time) (int, error) {
+	entries, err := os.ReadDir(s.tempDir(tenantID))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read temp directory: %w", err)
+	}
+	deleted := 0
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || !info.ModTime().Before(olderThan) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.tempDir(tenantID), entry.Name())); err != nil && !os.IsNotExist(err) {
+			return deleted, fmt.Errorf("failed to delete temp file %s: %w", entry.Name(), err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
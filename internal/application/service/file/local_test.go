@@ -2,7 +2,10 @@ package file
 
 import (
 	"context"
+	"io"
 	"net/url"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,3 +43,27 @@ func TestLocalGetFileURL_NoExternalURL(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "local://1/abc/img.png", got)
 }
+
+func TestLocalGetFileRange(t *testing.T) {
+	dir := t.TempDir()
+	svc := NewLocalFileService(dir, "")
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "1"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1", "doc.txt"), []byte("0123456789"), 0o644))
+
+	r, size, err := svc.GetFileRange(context.Background(), "local://1/doc.txt", 2, 3)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, r.Close())
+	require.NoError(t, err)
+	assert.Equal(t, "234", string(data))
+	assert.Equal(t, int64(10), size)
+
+	r, _, err = svc.GetFileRange(context.Background(), "local://1/doc.txt", 7, 0)
+	require.NoError(t, err)
+	data, _ = io.ReadAll(r)
+	r.Close()
+	assert.Equal(t, "789", string(data))
+
+	_, _, err = svc.GetFileRange(context.Background(), "local://1/doc.txt", 10, 0)
+	assert.ErrorIs(t, err, ErrInvalidRange)
+}
@@ -16,12 +16,17 @@ import (
 	"github.com/google/uuid"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/encrypt"
+	"github.com/minio/minio-go/v7/pkg/lifecycle"
 )
 
 // minioFileService MinIO file service implementation
 type minioFileService struct {
 	client     *minio.Client
 	bucketName string
+	multipart  multipartConfig
+	sse        serverSideEncryption
+	urls       urlOptions
 }
 
 // newMinioClient creates a bare minioFileService with just the SDK client initialised.
@@ -35,7 +40,7 @@ func newMinioClient(endpoint, accessKeyID, secretAccessKey, bucketName string, u
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize MinIO client: %w", err)
 	}
-	return &minioFileService{client: client, bucketName: bucketName}, nil
+	return &minioFileService{client: client, bucketName: bucketName, multipart: loadMultipartConfig(), urls: loadURLOptions()}, nil
 }
 
 // NewMinioFileService creates a MinIO file service.
@@ -56,11 +61,28 @@ func NewMinioFileService(endpoint,
 		if err = svc.client.MakeBucket(context.Background(), bucketName, minio.MakeBucketOptions{}); err != nil {
 			return nil, fmt.Errorf("failed to create bucket: %w", err)
 		}
+		svc.setTempLifecycle(context.Background())
 	}
 
 	return svc, nil
 }
 
+// setTempLifecycle sets an expiration rule for temp objects on a newly
+// created bucket. Failures are only logged; the cleanup job removes expired
+// temp files either way.
+func (s *minioFileService) setTempLifecycle(ctx context.Context) {
+	cfg := lifecycle.NewConfiguration()
+	cfg.Rules = []lifecycle.Rule{{
+		ID:         tempLifecycleRuleID,
+		Status:     "Enabled",
+		RuleFilter: lifecycle.Filter{Prefix: tempKeyPrefix("")},
+		Expiration: lifecycle.Expiration{Days: lifecycle.ExpirationDays(tempLifecycleDays())},
+	}}
+	if err := s.client.SetBucketLifecycle(ctx, s.bucketName, cfg); err != nil {
+		logger.Warnf(ctx, "Failed to set temp file lifecycle rule on bucket %s: %v", s.bucketName, err)
+	}
+}
+
 // CheckConnectivity verifies MinIO is reachable and, if a bucket is configured,
 // that the bucket exists. This is a read-only probe — it never creates a bucket.
 func (s *minioFileService) CheckConnectivity(ctx context.Context) error {
@@ -127,9 +149,21 @@ func (s *minioFileService) SaveFile(ctx context.Context,
 	}
 	defer src.Close()
 
+	// Large files go up in parts so a failure only costs the parts in flight
+	if s.multipart.useMultipart(file.Size) {
+		resumeKey := uploadResumeKey("minio://"+s.bucketName, tenantID, file.Filename, file.Size)
+		objectName, err = multipartUpload(ctx, s, s.multipart, resumeKey, objectName,
+			file.Header.Get("Content-Type"), src, file.Size)
+		if err != nil {
+			return "", fmt.Errorf("failed to upload file to MinIO: %w", err)
+		}
+		return fmt.Sprintf("minio://%s/%s", s.bucketName, objectName), nil
+	}
+
 	// Upload file to MinIO
 	_, err = s.client.PutObject(ctx, s.bucketName, objectName, src, file.Size, minio.PutObjectOptions{
-		ContentType: file.Header.Get("Content-Type"),
+		ContentType:          file.Header.Get("Content-Type"),
+		ServerSideEncryption: s.sse.minioServerSide(),
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to upload file to MinIO: %w", err)
@@ -138,6 +172,63 @@ func (s *minioFileService) SaveFile(ctx context.Context,
 	return fmt.Sprintf("minio://%s/%s", s.bucketName, objectName), nil
 }
 
+// createMultipartUpload and the methods below implement multipartBackend
+// on top of the low-level minio.Core API.
+func (s *minioFileService) createMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	core := minio.Core{Client: s.client}
+	return core.NewMultipartUpload(ctx, s.bucketName, key, minio.PutObjectOptions{
+		ContentType:          contentType,
+		ServerSideEncryption: s.sse.minioServerSide(),
+	})
+}
+
+func (s *minioFileService) uploadPart(ctx context.Context,
+	key, uploadID string, number int, body io.Reader, size int64,
+) (string, error) {
+	core := minio.Core{Client: s.client}
+	part, err := core.PutObjectPart(ctx, s.bucketName, key, uploadID, number, body, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return "", err
+	}
+	return part.ETag, nil
+}
+
+func (s *minioFileService) listParts(ctx context.Context, key, uploadID string) ([]uploadedPart, error) {
+	core := minio.Core{Client: s.client}
+	var parts []uploadedPart
+	marker := 0
+	for {
+		result, err := core.ListObjectParts(ctx, s.bucketName, key, uploadID, marker, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range result.ObjectParts {
+			parts = append(parts, uploadedPart{number: p.PartNumber, etag: p.ETag, size: p.Size})
+		}
+		if !result.IsTruncated {
+			return parts, nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
+
+func (s *minioFileService) completeMultipartUpload(ctx context.Context,
+	key, uploadID string, parts []uploadedPart,
+) error {
+	core := minio.Core{Client: s.client}
+	completed := make([]minio.CompletePart, len(parts))
+	for i, p := range parts {
+		completed[i] = minio.CompletePart{PartNumber: p.number, ETag: p.etag}
+	}
+	_, err := core.CompleteMultipartUpload(ctx, s.bucketName, key, uploadID, completed, minio.PutObjectOptions{})
+	return err
+}
+
+func (s *minioFileService) abortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	core := minio.Core{Client: s.client}
+	return core.AbortMultipartUpload(ctx, s.bucketName, key, uploadID)
+}
+
 // GetFile gets a file from MinIO
 func (s *minioFileService) GetFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
 	objectName, err := s.parseMinioFilePath(filePath)
@@ -151,6 +242,41 @@ func (s *minioFileService) GetFile(ctx context.Context, filePath string) (io.Rea
 	return obj, nil
 }
 
+// GetFileRange gets length bytes of a file starting at offset
+func (s *minioFileService) GetFileRange(ctx context.Context,
+	filePath string, offset, length int64,
+) (io.ReadCloser, int64, error) {
+	objectName, err := s.parseMinioFilePath(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	if offset < 0 {
+		return nil, 0, fmt.Errorf("invalid range offset %d", offset)
+	}
+	// GetObject is lazy and only fails on the first read, so stat first to
+	// validate the range and learn the object size.
+	info, err := s.client.StatObject(ctx, s.bucketName, objectName, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to stat file in MinIO: %w", err)
+	}
+	if offset >= info.Size {
+		return nil, info.Size, ErrInvalidRange
+	}
+	end := info.Size - 1
+	if length > 0 && offset+length-1 < end {
+		end = offset + length - 1
+	}
+	opts := minio.GetObjectOptions{}
+	if err := opts.SetRange(offset, end); err != nil {
+		return nil, 0, fmt.Errorf("invalid range: %w", err)
+	}
+	obj, err := s.client.GetObject(ctx, s.bucketName, objectName, opts)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get file from MinIO: %w", err)
+	}
+	return obj, info.Size, nil
+}
+
 // DeleteFile deletes a file
 func (s *minioFileService) DeleteFile(ctx context.Context, filePath string) error {
 	objectName, err := s.parseMinioFilePath(filePath)
@@ -180,7 +306,7 @@ func (s *minioFileService) CopyFile(ctx context.Context,
 	destKey := fmt.Sprintf("%d/%s/%s%s", tenantID, knowledgeID, uuid.New().String(), ext)
 
 	_, err = s.client.CopyObject(ctx,
-		minio.CopyDestOptions{Bucket: s.bucketName, Object: destKey},
+		minio.CopyDestOptions{Bucket: s.bucketName, Object: destKey, Encryption: s.sse.minioServerSide()},
 		minio.CopySrcOptions{Bucket: s.bucketName, Object: srcKey},
 	)
 	if err != nil {
@@ -193,7 +319,7 @@ func (s *minioFileService) CopyFile(ctx context.Context,
 }
 
 // SaveBytes saves bytes data to MinIO and returns the file path
-// temp parameter is ignored for MinIO (no auto-expiration support in this implementation)
+// Temp files go under the temp/ prefix, where they expire; see temp.go.
 func (s *minioFileService) SaveBytes(ctx context.Context, data []byte, tenantID uint64, fileName string, temp bool) (string, error) {
 	safeName, err := utils.SafeFileName(fileName)
 	if err != nil {
@@ -201,11 +327,15 @@ func (s *minioFileService) SaveBytes(ctx context.Context, data []byte, tenantID
 	}
 	ext := filepath.Ext(safeName)
 	objectName := fmt.Sprintf("%d/exports/%s%s", tenantID, uuid.New().String(), ext)
+	if temp {
+		objectName = tempObjectKey("", tenantID, ext)
+	}
 
 	// Upload bytes to MinIO
 	reader := bytes.NewReader(data)
 	_, err = s.client.PutObject(ctx, s.bucketName, objectName, reader, int64(len(data)), minio.PutObjectOptions{
-		ContentType: utils.GetContentTypeByExt(ext),
+		ContentType:          utils.GetContentTypeByExt(ext),
+		ServerSideEncryption: s.sse.minioServerSide(),
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to upload bytes to MinIO: %w", err)
@@ -214,15 +344,117 @@ func (s *minioFileService) SaveBytes(ctx context.Context, data []byte, tenantID
 	return fmt.Sprintf("minio://%s/%s", s.bucketName, objectName), nil
 }
 
+// SaveDerivedFile stores data next to the object at filePath, under the
+// same server-side encryption.
+func (s *minioFileService) SaveDerivedFile(ctx context.Context, filePath, suffix string, data []byte) (string, error) {
+	objectName, err := s.parseMinioFilePath(filePath)
+	if err != nil {
+		return "", err
+	}
+	key, err := derivedObjectKey(objectName, suffix)
+	if err != nil {
+		return "", err
+	}
+	_, err = s.client.PutObject(ctx, s.bucketName, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType:          utils.GetContentTypeByExt(filepath.Ext(suffix)),
+		ServerSideEncryption: s.sse.minioServerSide(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload derived file to MinIO: %w", err)
+	}
+	return filePath + suffix, nil
+}
+
 // GetFileURL returns a presigned download URL for the file
 func (s *minioFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
+	return s.GetFileURLWithExpiry(ctx, filePath, 0)
+}
+
+// GetFileURLWithExpiry returns a presigned download URL valid for expiry,
+// or for the configured default when expiry <= 0.
+func (s *minioFileService) GetFileURLWithExpiry(ctx context.Context, filePath string, expiry time.Duration) (string, error) {
+	ttl := s.urls.expiryFor(expiry, defaultURLExpiry)
 	objectName, err := s.parseMinioFilePath(filePath)
 	if err != nil {
 		return "", err
 	}
-	presignedURL, err := s.client.PresignedGetObject(ctx, s.bucketName, objectName, 24*time.Hour, nil)
+	presignedURL, err := s.client.PresignedGetObject(ctx, s.bucketName, objectName, ttl, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
 	}
-	return presignedURL.String(), nil
+	return s.urls.rewrite(presignedURL.String()), nil
+}
+
+func (s *minioFileService) setURLOptions(o urlOptions) {
+	s.urls = s.urls.merge(o)
+}
+
+// minioServerSide returns the SSE settings of MinIO writes, nil when
+// encryption is off.
+func (e serverSideEncryption) minioServerSide() encrypt.ServerSide {
+	switch {
+	case e.kms():
+		// NewSSEKMS only fails to marshal a KMS context, and none is passed.
+		sse, _ := encrypt.NewSSEKMS(e.kmsKeyID, nil)
+		return sse
+	case e.enabled():
+		return encrypt.NewSSE()
+	default:
+		return nil
+	}
+}
+
+func (s *minioFileService) setEncryption(sse serverSideEncryption) {
+	s.sse = sse
+}
+
+// EncryptionKeyID returns the key new objects are encrypted with; see
+// interfaces.EncryptedFileService.
+func (s *minioFileService) EncryptionKeyID() string {
+	return s.sse.keyID()
+}
+
+// ReencryptFile rewrites the object in place with a server-side copy under
+// the current encryption settings.
+func (s *minioFileService) ReencryptFile(ctx context.Context, filePath string) error {
+	objectName, err := s.parseMinioFilePath(filePath)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.CopyObject(ctx,
+		minio.CopyDestOptions{Bucket: s.bucketName, Object: objectName, Encryption: s.sse.minioServerSide()},
+		minio.CopySrcOptions{Bucket: s.bucketName, Object: objectName},
+	)
+	if err != nil {
+		return fmt.Errorf("failed to re-encrypt file in MinIO: %w", err)
+	}
+	return nil
+}
+
+// CleanupTempFiles deletes tenantID's temp files last modified before
+// olderThan; see interfaces.TempFileCleaner.
+func (s *minioFileService) CleanupTempFiles(ctx context.Context, tenantID uint64, olderThan time.Time) (int, error) {
+	// Cancelling stops the lister goroutine when we return mid-listing.
+	listCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	deleted := 0
+	objects := s.client.ListObjects(listCtx, s.bucketName, minio.ListObjectsOptions{
+		Prefix:    tempTenantPrefix("", tenantID),
+		Recursive: true,
+	})
+	for obj := range objects {
+		if obj.Err != nil {
+			return deleted, fmt.Errorf("failed to list temp files: %w", obj.Err)
+		}
+		if !obj.LastModified.Before(olderThan) {
+			continue
+		}
+		if err := s.client.RemoveObject(ctx, s.bucketName, obj.Key, minio.RemoveObjectOptions{}); err != nil {
+			return deleted, fmt.Errorf("failed to delete %s: %w", obj.Key, err)
+		}
+		deleted++
+	}
+	return deleted, nil
 }
@@ -0,0 +1,222 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+)
+
+// Multipart upload tuning shared by every object storage backend. Sizes are
+// in MiB.
+const (
+	envMultipartThresholdMB = "FILE_MULTIPART_THRESHOLD_MB"
+	envMultipartPartSizeMB  = "FILE_MULTIPART_PART_SIZE_MB"
+	envMultipartConcurrency = "FILE_MULTIPART_CONCURRENCY"
+
+	defaultMultipartThreshold   = 64 << 20
+	defaultMultipartPartSize    = 16 << 20
+	defaultMultipartConcurrency = 4
+	// minMultipartPartSize is the S3-compatible lower bound for every part
+	// but the last one.
+	minMultipartPartSize = 5 << 20
+	maxMultipartParts    = 10000
+	maxMultipartWorkers  = 32
+	// pendingUploadTTL is how long an unfinished upload stays resumable
+	// before it is aborted.
+	pendingUploadTTL = 24 * time.Hour
+)
+
+// multipartConfig decides when and how SaveFile splits a file into parts.
+type multipartConfig struct {
+	threshold   int64
+	partSize    int64
+	concurrency int
+}
+
+// loadMultipartConfig reads the FILE_MULTIPART_* variables, falling back to
+// the defaults for unset or invalid values.
+func loadMultipartConfig() multipartConfig {
+	cfg := multipartConfig{
+		threshold:   defaultMultipartThreshold,
+		partSize:    defaultMultipartPartSize,
+		concurrency: defaultMultipartConcurrency,
+	}
+	if mb, err := strconv.ParseInt(strings.TrimSpace(os.Getenv(envMultipartThresholdMB)), 10, 64); err == nil && mb > 0 {
+		cfg.threshold = mb << 20
+	}
+	if mb, err := strconv.ParseInt(strings.TrimSpace(os.Getenv(envMultipartPartSizeMB)), 10, 64); err == nil && mb > 0 {
+		cfg.partSize = max(mb<<20, minMultipartPartSize)
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(envMultipartConcurrency))); err == nil && n > 0 {
+		cfg.concurrency = min(n, maxMultipartWorkers)
+	}
+	return cfg
+}
+
+// useMultipart reports whether a file of the given size is uploaded in parts.
+func (c multipartConfig) useMultipart(size int64) bool {
+	return size >= c.threshold && size > c.partSize
+}
+
+// partSizeFor returns the configured part size, grown when needed so the
+// file fits in maxMultipartParts parts.
+func (c multipartConfig) partSizeFor(size int64) int64 {
+	partSize := c.partSize
+	if minSize := (size + maxMultipartParts - 1) / maxMultipartParts; partSize < minSize {
+		partSize = (minSize + 1<<20 - 1) &^ (1<<20 - 1)
+	}
+	return partSize
+}
+
+// uploadedPart is one part of a multipart upload as the backend reports it.
+type uploadedPart struct {
+	number int
+	etag   string
+	size   int64
+}
+
+// multipartBackend is the provider-specific half of a multipart upload.
+// Implementations map each call onto their SDK and operate on the
+// service's own bucket.
+type multipartBackend interface {
+	createMultipartUpload(ctx context.Context, key, contentType string) (string, error)
+	uploadPart(ctx context.Context, key, uploadID string, number int, body io.Reader, size int64) (string, error)
+	listParts(ctx context.Context, key, uploadID string) ([]uploadedPart, error)
+	completeMultipartUpload(ctx context.Context, key, uploadID string, parts []uploadedPart) error
+	abortMultipartUpload(ctx context.Context, key, uploadID string) error
+}
+
+// pendingUpload is an upload that failed part-way and can be resumed.
+type pendingUpload struct {
+	key      string
+	uploadID string
+	backend  multipartBackend
+	started  time.Time
+}
+
+// pendingUploads remembers unfinished uploads by resume key, so a retried
+// upload of the same file picks up the upload ID and skips parts the
+// backend already holds. It lives in process memory: uploads interrupted by
+// a restart are left to the bucket's lifecycle rules.
+var pendingUploads = &pendingUploadRegistry{uploads: make(map[string]pendingUpload)}
+
+type pendingUploadRegistry struct {
+	mu      sync.Mutex
+	uploads map[string]pendingUpload
+}
+
+// take removes and returns the pending upload for resumeKey. Expired
+// uploads are aborted in the background.
+func (r *pendingUploadRegistry) take(resumeKey string) (pendingUpload, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for k, p := range r.uploads {
+		if time.Since(p.started) > pendingUploadTTL {
+			delete(r.uploads, k)
+			go func() {
+				_ = p.backend.abortMultipartUpload(context.Background(), p.key, p.uploadID)
+			}()
+		}
+	}
+	p, ok := r.uploads[resumeKey]
+	delete(r.uploads, resumeKey)
+	return p, ok
+}
+
+func (r *pendingUploadRegistry) put(resumeKey string, p pendingUpload) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.uploads[resumeKey] = p
+}
+
+// uploadResumeKey identifies an upload across retries. Parts are checked
+// against their MD5 before being reused, so two different files that
+// collide on the key only cost a re-upload.
+func uploadResumeKey(location string, tenantID uint64, fileName string, size int64) string {
+	return fmt.Sprintf("%s|%d|%s|%d", location, tenantID, fileName, size)
+}
+
+// multipartUpload uploads src to key in parts of cfg.partSizeFor(size),
+// cfg.concurrency at a time. When an earlier attempt under resumeKey failed
+// part-way, its upload is resumed instead: the object lands at that
+// attempt's key and parts whose MD5 matches the stored ETag are skipped.
+// Returns the key the object was written to. On failure the upload stays
+// resumable rather than being aborted.
+func multipartUpload(ctx context.Context, backend multipartBackend, cfg multipartConfig,
+	resumeKey, key, contentType string, src io.ReaderAt, size int64,
+) (string, error) {
+	var uploadID string
+	started := time.Now()
+	existing := map[int]uploadedPart{}
+	if p, ok := pendingUploads.take(resumeKey); ok {
+		parts, err := backend.listParts(ctx, p.key, p.uploadID)
+		if err != nil {
+			logger.Warnf(ctx, "Cannot resume multipart upload %s of %s, starting over: %v", p.uploadID, p.key, err)
+		} else {
+			key, uploadID, started = p.key, p.uploadID, p.started
+			for _, part := range parts {
+				existing[part.number] = part
+			}
+			logger.Infof(ctx, "Resuming multipart upload %s of %s with %d stored parts", uploadID, key, len(parts))
+		}
+	}
+	if uploadID == "" {
+		var err error
+		if uploadID, err = backend.createMultipartUpload(ctx, key, contentType); err != nil {
+			return "", fmt.Errorf("failed to start multipart upload: %w", err)
+		}
+	}
+
+	partSize := cfg.partSizeFor(size)
+	numParts := int((size + partSize - 1) / partSize)
+	parts := make([]uploadedPart, numParts)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(cfg.concurrency)
+	for i := range numParts {
+		g.Go(func() error {
+			number := i + 1
+			offset := int64(i) * partSize
+			length := min(partSize, size-offset)
+
+			buf := make([]byte, length)
+			if _, err := src.ReadAt(buf, offset); err != nil && err != io.EOF {
+				return fmt.Errorf("read part %d: %w", number, err)
+			}
+			sum := md5.Sum(buf)
+			if stored, ok := existing[number]; ok && stored.size == length &&
+				strings.Trim(stored.etag, `"`) == hex.EncodeToString(sum[:]) {
+				parts[i] = stored
+				return nil
+			}
+
+			etag, err := backend.uploadPart(gctx, key, uploadID, number, bytes.NewReader(buf), length)
+			if err != nil {
+				return fmt.Errorf("upload part %d: %w", number, err)
+			}
+			parts[i] = uploadedPart{number: number, etag: etag, size: length}
+			return nil
+		})
+	}
+	err := g.Wait()
+	if err == nil {
+		err = backend.completeMultipartUpload(ctx, key, uploadID, parts)
+	}
+	if err != nil {
+		pendingUploads.put(resumeKey, pendingUpload{key: key, uploadID: uploadID, backend: backend, started: started})
+		return "", fmt.Errorf("multipart upload %s failed, retry to resume: %w", uploadID, err)
+	}
+	return key, nil
+}
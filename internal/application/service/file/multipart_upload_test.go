@@ -0,0 +1,144 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeMultipartBackend keeps parts in memory and can fail one part number
+// once.
+type fakeMultipartBackend struct {
+	mu        sync.Mutex
+	uploads   map[string]map[int][]byte
+	completed map[string][]byte
+	failPart  int
+	uploaded  int
+	created   int
+}
+
+func newFakeMultipartBackend() *fakeMultipartBackend {
+	return &fakeMultipartBackend{uploads: map[string]map[int][]byte{}, completed: map[string][]byte{}}
+}
+
+func (f *fakeMultipartBackend) createMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.created++
+	id := fmt.Sprintf("upload-%d", f.created)
+	f.uploads[id] = map[int][]byte{}
+	return id, nil
+}
+
+func (f *fakeMultipartBackend) uploadPart(ctx context.Context,
+	key, uploadID string, number int, body io.Reader, size int64,
+) (string, error) {
+	data, _ := io.ReadAll(body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if number == f.failPart {
+		f.failPart = 0
+		return "", errors.New("connection reset")
+	}
+	f.uploaded++
+	f.uploads[uploadID][number] = data
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`, nil
+}
+
+func (f *fakeMultipartBackend) listParts(ctx context.Context, key, uploadID string) ([]uploadedPart, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var parts []uploadedPart
+	for number, data := range f.uploads[uploadID] {
+		sum := md5.Sum(data)
+		parts = append(parts, uploadedPart{number: number, etag: hex.EncodeToString(sum[:]), size: int64(len(data))})
+	}
+	return parts, nil
+}
+
+func (f *fakeMultipartBackend) completeMultipartUpload(ctx context.Context,
+	key, uploadID string, parts []uploadedPart,
+) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var buf bytes.Buffer
+	for i, p := range parts {
+		if p.number != i+1 {
+			return fmt.Errorf("part %d out of order", p.number)
+		}
+		buf.Write(f.uploads[uploadID][p.number])
+	}
+	f.completed[key] = buf.Bytes()
+	return nil
+}
+
+func (f *fakeMultipartBackend) abortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	return nil
+}
+
+func TestMultipartUploadResumesFailedUpload(t *testing.T) {
+	ctx := context.Background()
+	backend := newFakeMultipartBackend()
+	cfg := multipartConfig{threshold: 1, partSize: minMultipartPartSize, concurrency: 2}
+	data := bytes.Repeat([]byte("0123456789abcdef"), (4*minMultipartPartSize+1024)/16)
+	resumeKey := uploadResumeKey("fake://bucket", 1, "video.mp4", int64(len(data)))
+
+	backend.failPart = 3
+	_, err := multipartUpload(ctx, backend, cfg, resumeKey, "1/k1/a.mp4", "video/mp4", bytes.NewReader(data), int64(len(data)))
+	if err == nil {
+		t.Fatal("expected the first attempt to fail")
+	}
+	uploadedBefore := backend.uploaded
+
+	key, err := multipartUpload(ctx, backend, cfg, resumeKey, "1/k2/b.mp4", "video/mp4", bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "1/k1/a.mp4" {
+		t.Fatalf("resumed upload wrote to %q, want the first attempt's key", key)
+	}
+	if backend.created != 1 {
+		t.Fatalf("got %d multipart uploads, want the first one resumed", backend.created)
+	}
+	if reuploaded := backend.uploaded - uploadedBefore; reuploaded != 5-uploadedBefore {
+		t.Fatalf("re-uploaded %d parts, want only the %d missing ones", reuploaded, 5-uploadedBefore)
+	}
+	if !bytes.Equal(backend.completed[key], data) {
+		t.Fatal("completed object does not match the source")
+	}
+}
+
+func TestMultipartPartSizeFor(t *testing.T) {
+	cfg := multipartConfig{partSize: minMultipartPartSize}
+	if got := cfg.partSizeFor(100 << 20); got != minMultipartPartSize {
+		t.Fatalf("partSizeFor(100MiB) = %d, want configured size", got)
+	}
+	size := int64(100 << 30)
+	got := cfg.partSizeFor(size)
+	if (size+got-1)/got > maxMultipartParts {
+		t.Fatalf("partSizeFor(100GiB) = %d needs more than %d parts", got, maxMultipartParts)
+	}
+}
+
+func TestLoadMultipartConfig(t *testing.T) {
+	t.Setenv(envMultipartThresholdMB, "128")
+	t.Setenv(envMultipartPartSizeMB, "1")
+	t.Setenv(envMultipartConcurrency, "bogus")
+	cfg := loadMultipartConfig()
+	if cfg.threshold != 128<<20 {
+		t.Fatalf("threshold = %d", cfg.threshold)
+	}
+	if cfg.partSize != minMultipartPartSize {
+		t.Fatalf("part size below the S3 minimum was not raised: %d", cfg.partSize)
+	}
+	if cfg.concurrency != defaultMultipartConcurrency {
+		t.Fatalf("invalid concurrency not ignored: %d", cfg.concurrency)
+	}
+}
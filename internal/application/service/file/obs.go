@@ -12,6 +12,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -25,6 +26,8 @@ type obsFileService struct {
 	region      string
 	pathPrefix  string
 	proxyDomain string
+	multipart   multipartConfig
+	urls        urlOptions
 }
 
 type obsEndpointResolver struct {
@@ -60,6 +63,8 @@ func NewObsFileService(
 		})
 		if createErr != nil {
 			fmt.Printf("Warning: bucket %s may not exist or cannot be created: %v\n", bucketName, createErr)
+		} else {
+			putS3TempLifecycle(context.Background(), client, bucketName, tempKeyPrefix(obsKeyPrefix(pathPrefix)))
 		}
 	}
 
@@ -72,6 +77,8 @@ func NewObsFileService(
 		region:      region,
 		pathPrefix:  strings.Trim(pathPrefix, "/"),
 		proxyDomain: proxyDomain,
+		multipart:   loadMultipartConfig(),
+		urls:        loadURLOptions(),
 	}, nil
 }
 
@@ -131,6 +138,15 @@ func (s *obsFileService) parseObsFilePath(filePath string) (string, error) {
 	return filePath, nil
 }
 
+// obsKeyPrefix turns the configured path prefix into a key prefix that is
+// empty or ends with "/".
+func obsKeyPrefix(pathPrefix string) string {
+	if pathPrefix = strings.Trim(pathPrefix, "/"); pathPrefix != "" {
+		return pathPrefix + "/"
+	}
+	return ""
+}
+
 func (s *obsFileService) getPrifix() string {
 	if s.proxyDomain != "" {
 		return s.proxyDomain + "/"
@@ -161,14 +177,20 @@ func (s *obsFileService) SaveFile(ctx context.Context,
 		contentType = "application/octet-stream"
 	}
 
-	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:        aws.String(s.bucketName),
-		Key:           aws.String(objectKey),
-		Body:          src,
-		ContentLength: aws.Int64(file.Size),
-		ContentType:   aws.String(contentType),
-		// ACL:           "private",
-	})
+	if s.multipart.useMultipart(file.Size) {
+		resumeKey := uploadResumeKey("obs://"+s.bucketName, tenantID, file.Filename, file.Size)
+		backend := s3MultipartBackend{client: s.client, bucketName: s.bucketName}
+		objectKey, err = multipartUpload(ctx, backend, s.multipart, resumeKey, objectKey, contentType, src, file.Size)
+	} else {
+		_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket:        aws.String(s.bucketName),
+			Key:           aws.String(objectKey),
+			Body:          src,
+			ContentLength: aws.Int64(file.Size),
+			ContentType:   aws.String(contentType),
+			// ACL:           "private",
+		})
+	}
 	if err != nil {
 		return "", fmt.Errorf("failed to upload file to OBS: %w", err)
 	}
@@ -196,6 +218,31 @@ func (s *obsFileService) GetFile(ctx context.Context, filePath string) (io.ReadC
 	return output.Body, nil
 }
 
+// GetFileRange gets length bytes of a file starting at offset
+func (s *obsFileService) GetFileRange(ctx context.Context,
+	filePath string, offset, length int64,
+) (io.ReadCloser, int64, error) {
+	objectKey, err := s.parseObsFilePath(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	byteRange, err := rangeHeader(offset, length)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(objectKey),
+		Range:  aws.String(byteRange),
+	})
+	if err != nil {
+		return nil, 0, wrapRangeError("OBS", err)
+	}
+
+	return resp.Body, contentRangeSize(aws.ToString(resp.ContentRange)), nil
+}
+
 func (s *obsFileService) DeleteFile(ctx context.Context, filePath string) error {
 	objectKey, err := s.parseObsFilePath(filePath)
 	if err != nil {
@@ -214,6 +261,12 @@ func (s *obsFileService) DeleteFile(ctx context.Context, filePath string) error
 }
 
 func (s *obsFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
+	return s.GetFileURLWithExpiry(ctx, filePath, 0)
+}
+
+// GetFileURLWithExpiry returns the same public URL as GetFileURL: OBS links
+// are not presigned, so expiry is ignored and only the CDN host applies.
+func (s *obsFileService) GetFileURLWithExpiry(ctx context.Context, filePath string, expiry time.Duration) (string, error) {
 	if strings.HasPrefix(filePath, "http://") || strings.HasPrefix(filePath, "https://") {
 		return filePath, nil
 	}
@@ -224,10 +277,14 @@ func (s *obsFileService) GetFileURL(ctx context.Context, filePath string) (strin
 	}
 
 	if s.proxyDomain != "" {
-		return s.proxyDomain + "/" + strings.TrimPrefix(objectKey, "/"), nil
+		return s.urls.rewrite(s.proxyDomain + "/" + strings.TrimPrefix(objectKey, "/")), nil
 	}
 
-	return fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucketName, strings.TrimPrefix(objectKey, "/")), nil
+	return s.urls.rewrite(fmt.Sprintf("%s/%s/%s", s.endpoint, s.bucketName, strings.TrimPrefix(objectKey, "/"))), nil
+}
+
+func (s *obsFileService) setURLOptions(o urlOptions) {
+	s.urls = s.urls.merge(o)
 }
 
 // CopyFile copies an existing OBS object to a new knowledge-owned object using a
@@ -283,11 +340,7 @@ func (s *obsFileService) SaveBytes(ctx context.Context, data []byte, tenantID ui
 
 	var objectKey string
 	if temp {
-		if s.pathPrefix != "" {
-			objectKey = fmt.Sprintf("%s/temp/%d/%s%s", s.pathPrefix, tenantID, uuid.New().String(), ext)
-		} else {
-			objectKey = fmt.Sprintf("temp/%d/%s%s", tenantID, uuid.New().String(), ext)
-		}
+		objectKey = tempObjectKey(obsKeyPrefix(s.pathPrefix), tenantID, ext)
 	} else {
 		if s.pathPrefix != "" {
 			objectKey = fmt.Sprintf("%s/%d/%s%s", s.pathPrefix, tenantID, uuid.New().String(), ext)
@@ -313,3 +366,33 @@ func (s *obsFileService) SaveBytes(ctx context.Context, data []byte, tenantID ui
 	}
 	return fmt.Sprintf("%s%s/%s", prefix, s.bucketName, objectKey), nil
 }
+
+// SaveDerivedFile stores data next to the object at filePath, publicly
+// readable like every OBS object this service writes.
+func (s *obsFileService) SaveDerivedFile(ctx context.Context, filePath, suffix string, data []byte) (string, error) {
+	objectKey, err := s.parseObsFilePath(filePath)
+	if err != nil {
+		return "", err
+	}
+	key, err := derivedObjectKey(objectKey, suffix)
+	if err != nil {
+		return "", err
+	}
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucketName),
+		Key:         aws.String(key),
+		Body:        strings.NewReader(string(data)),
+		ContentType: aws.String(utils.GetContentTypeByExt(filepath.Ext(suffix))),
+		ACL:         "public-read",
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload derived file to OBS: %w", err)
+	}
+	return filePath + suffix, nil
+}
+
+// CleanupTempFiles deletes tenantID's temp files last modified before
+// olderThan; see interfaces.TempFileCleaner.
+func (s *obsFileService) CleanupTempFiles(ctx context.Context, tenantID uint64, olderThan time.Time) (int, error) {
+	return deleteS3ObjectsBefore(ctx, s.client, s.bucketName, tempTenantPrefix(obsKeyPrefix(s.pathPrefix), tenantID), olderThan)
+}
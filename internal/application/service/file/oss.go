@@ -28,6 +28,8 @@ type ossFileService struct {
 	pathPrefix     string
 	bucketName     string
 	tempBucketName string
+	multipart      multipartConfig
+	urls           urlOptions
 }
 
 const ossScheme = "oss://"
@@ -109,6 +111,8 @@ func NewOssFileServiceWithTempBucket(endpoint, region, accessKey, secretKey, buc
 		pathPrefix:     pathPrefix,
 		bucketName:     bucketName,
 		tempBucketName: tempBucketName,
+		multipart:      loadMultipartConfig(),
+		urls:           loadURLOptions(),
 	}, nil
 }
 
@@ -176,12 +180,12 @@ func (s *ossFileService) SaveFile(ctx context.Context,
 		contentType = utils.GetContentTypeByExt(ext)
 	}
 
-	// Use Uploader for files > 10MB (auto multipart with concurrent uploads)
-	const multipartThreshold = 10 * 1024 * 1024
-	if file.Size > multipartThreshold {
+	// Use the SDK Uploader for large files (auto multipart with concurrent
+	// uploads), tuned by the shared FILE_MULTIPART_* settings
+	if s.multipart.useMultipart(file.Size) {
 		uploader := s.client.NewUploader(func(uo *oss.UploaderOptions) {
-			uo.PartSize = 10 * 1024 * 1024 // 10MB per part
-			uo.ParallelNum = 3             // 3 concurrent uploads
+			uo.PartSize = s.multipart.partSizeFor(file.Size)
+			uo.ParallelNum = s.multipart.concurrency
 		})
 
 		_, err = uploader.UploadFrom(ctx,
@@ -243,6 +247,36 @@ func (s *ossFileService) SaveBytes(ctx context.Context, data []byte, tenantID ui
 	return fmt.Sprintf("oss://%s/%s", targetBucket, objectName), nil
 }
 
+// SaveDerivedFile stores data next to the object at filePath.
+func (s *ossFileService) SaveDerivedFile(ctx context.Context, filePath, suffix string, data []byte) (string, error) {
+	bucketName, objectName, err := parseOssFilePath(filePath)
+	if err != nil {
+		return "", err
+	}
+	if err := utils.SafeObjectKey(objectName); err != nil {
+		return "", fmt.Errorf("invalid file path: %w", err)
+	}
+	key, err := derivedObjectKey(objectName, suffix)
+	if err != nil {
+		return "", err
+	}
+
+	client := s.client
+	if bucketName == s.tempBucketName && s.tempClient != nil {
+		client = s.tempClient
+	}
+	_, err = client.PutObject(ctx, &oss.PutObjectRequest{
+		Bucket:      oss.Ptr(bucketName),
+		Key:         oss.Ptr(key),
+		Body:        bytes.NewReader(data),
+		ContentType: oss.Ptr(utils.GetContentTypeByExt(filepath.Ext(suffix))),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload derived file to OSS: %w", err)
+	}
+	return filePath + suffix, nil
+}
+
 // CopyFile copies an existing OSS object to a new knowledge-owned object using a
 // server-side CopyObject (no data leaves OSS). The destination uses the same
 // layout as SaveFile. Returns ErrCrossBackendCopy when srcPath is not an oss:// path.
@@ -303,6 +337,44 @@ func (s *ossFileService) GetFile(ctx context.Context, filePath string) (io.ReadC
 	return resp.Body, nil
 }
 
+// GetFileRange retrieves length bytes of a file from OSS starting at offset.
+func (s *ossFileService) GetFileRange(ctx context.Context,
+	filePath string, offset, length int64,
+) (io.ReadCloser, int64, error) {
+	bucketName, objectName, err := parseOssFilePath(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := utils.SafeObjectKey(objectName); err != nil {
+		return nil, 0, fmt.Errorf("invalid file path: %w", err)
+	}
+	byteRange, err := rangeHeader(offset, length)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var client *oss.Client
+	if bucketName == s.tempBucketName && s.tempClient != nil {
+		client = s.tempClient
+	} else {
+		client = s.client
+	}
+
+	// The "standard" behavior makes OSS reject out-of-bounds ranges instead
+	// of silently returning the whole object.
+	resp, err := client.GetObject(ctx, &oss.GetObjectRequest{
+		Bucket:        oss.Ptr(bucketName),
+		Key:           oss.Ptr(objectName),
+		Range:         oss.Ptr(byteRange),
+		RangeBehavior: oss.Ptr("standard"),
+	})
+	if err != nil {
+		return nil, 0, wrapRangeError("OSS", err)
+	}
+
+	return resp.Body, contentRangeSize(oss.ToString(resp.ContentRange)), nil
+}
+
 // DeleteFile removes a file from OSS.
 func (s *ossFileService) DeleteFile(ctx context.Context, filePath string) error {
 	bucketName, objectName, err := parseOssFilePath(filePath)
@@ -333,6 +405,13 @@ func (s *ossFileService) DeleteFile(ctx context.Context, filePath string) error
 
 // GetFileURL returns a presigned download URL for the file.
 func (s *ossFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
+	return s.GetFileURLWithExpiry(ctx, filePath, 0)
+}
+
+// GetFileURLWithExpiry returns a presigned download URL valid for expiry,
+// or for the configured default when expiry <= 0.
+func (s *ossFileService) GetFileURLWithExpiry(ctx context.Context, filePath string, expiry time.Duration) (string, error) {
+	ttl := s.urls.expiryFor(expiry, defaultURLExpiry)
 	bucketName, objectName, err := parseOssFilePath(filePath)
 	if err != nil {
 		return "", err
@@ -349,14 +428,18 @@ func (s *ossFileService) GetFileURL(ctx context.Context, filePath string) (strin
 		client = s.client
 	}
 
-	// Generate presigned URL (valid for 24 hours)
+	// Generate presigned URL
 	result, err := client.Presign(ctx, &oss.GetObjectRequest{
 		Bucket: oss.Ptr(bucketName),
 		Key:    oss.Ptr(objectName),
-	}, oss.PresignExpires(24*time.Hour))
+	}, oss.PresignExpires(ttl))
 	if err != nil {
 		return "", fmt.Errorf("failed to generate OSS presigned URL: %w", err)
 	}
 
-	return result.URL, nil
+	return s.urls.rewrite(result.URL), nil
+}
+
+func (s *ossFileService) setURLOptions(o urlOptions) {
+	s.urls = s.urls.merge(o)
 }
@@ -0,0 +1,180 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // register GIF decoding for thumbnails
+	"image/jpeg"
+	_ "image/png" // register PNG decoding for thumbnails
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// PreviewSuffix is appended to a stored file's key to name its preview.
+	PreviewSuffix = ".thumb.jpg"
+	// PreviewMaxSourceBytes bounds the files previews are generated for; the
+	// whole file is held in memory while its preview is rendered.
+	PreviewMaxSourceBytes = 50 << 20
+
+	previewMaxDimension = 320
+	previewJPEGQuality  = 80
+	// previewMaxPixels rejects images whose decoded form would not fit
+	// comfortably in memory, whatever their compressed size.
+	previewMaxPixels  = 50_000_000
+	pdfPreviewTimeout = 30 * time.Second
+)
+
+// ErrPreviewUnsupported is returned when no preview can be made for a file
+// type, or the file service cannot store one next to the original.
+var ErrPreviewUnsupported = errors.New("preview not supported")
+
+// pdftoppmPath locates poppler's pdftoppm, which renders PDF previews.
+// Overridable in tests.
+var pdftoppmPath = func() (string, error) { return exec.LookPath("pdftoppm") }
+
+// PreviewSupported reports whether GeneratePreview can handle fileType
+// (the extension without its dot).
+func PreviewSupported(fileType string) bool {
+	switch strings.ToLower(fileType) {
+	case "jpg", "jpeg", "png", "gif":
+		return true
+	case "pdf":
+		_, err := pdftoppmPath()
+		return err == nil
+	default:
+		return false
+	}
+}
+
+// GeneratePreview renders a JPEG thumbnail, at most 320px on its longer
+// side, of an image or of the first page of a PDF.
+func GeneratePreview(ctx context.Context, fileType string, data []byte) ([]byte, error) {
+	switch strings.ToLower(fileType) {
+	case "jpg", "jpeg", "png", "gif":
+		return imageThumbnail(data)
+	case "pdf":
+		return pdfFirstPage(ctx, data)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrPreviewUnsupported, fileType)
+	}
+}
+
+// SavePreview stores preview next to filePath and returns its path.
+func SavePreview(ctx context.Context, svc interfaces.FileService, filePath string, preview []byte) (string, error) {
+	w, ok := As[interfaces.DerivedFileWriter](svc)
+	if !ok {
+		return "", ErrPreviewUnsupported
+	}
+	return w.SaveDerivedFile(ctx, filePath, PreviewSuffix, preview)
+}
+
+func imageThumbnail(data []byte) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	if cfg.Width*cfg.Height > previewMaxPixels {
+		return nil, fmt.Errorf("image too large for a preview: %dx%d", cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaleDown(img, previewMaxDimension), &jpeg.Options{Quality: previewJPEGQuality}); err != nil {
+		return nil, fmt.Errorf("encode preview: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleDown flattens img onto white, since JPEG has no alpha, and shrinks
+// it to fit in a maxDim square by averaging the source pixels behind each
+// target pixel. Smaller images keep their size.
+func scaleDown(img image.Image, maxDim int) *image.RGBA {
+	b := img.Bounds()
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Over)
+
+	sw, sh := b.Dx(), b.Dy()
+	dw, dh := sw, sh
+	if sw > maxDim || sh > maxDim {
+		if sw >= sh {
+			dw, dh = maxDim, max(1, sh*maxDim/sw)
+		} else {
+			dw, dh = max(1, sw*maxDim/sh), maxDim
+		}
+	}
+	if dw == sw && dh == sh {
+		return src
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*sh/dh, max((y+1)*sh/dh, y*sh/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*sw/dw, max((x+1)*sw/dw, x*sw/dw+1)
+			var r, g, bl, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+3]
+					r, g, bl = r+int(p[0]), g+int(p[1]), bl+int(p[2])
+					n++
+				}
+			}
+			i := y*dst.Stride + x*4
+			dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = uint8(r/n), uint8(g/n), uint8(bl/n), 0xff
+		}
+	}
+	return dst
+}
+
+// pdfFirstPage renders page 1 of a PDF with pdftoppm.
+func pdfFirstPage(ctx context.Context, data []byte) ([]byte, error) {
+	bin, err := pdftoppmPath()
+	if err != nil {
+		return nil, fmt.Errorf("%w: pdftoppm not installed", ErrPreviewUnsupported)
+	}
+	dir, err := os.MkdirTemp("", "weknora-preview-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "in.pdf")
+	if err := os.WriteFile(in, data, 0o600); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, pdfPreviewTimeout)
+	defer cancel()
+	out := filepath.Join(dir, "page")
+	cmd := exec.CommandContext(ctx, bin, "-f", "1", "-l", "1", "-singlefile", "-jpeg",
+		"-jpegopt", "quality="+strconv.Itoa(previewJPEGQuality),
+		"-scale-to", strconv.Itoa(previewMaxDimension), in, out)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("pdftoppm: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return os.ReadFile(out + ".jpg")
+}
+
+// derivedObjectKey names the object holding an asset derived from key.
+// suffix may not leave the original's directory.
+func derivedObjectKey(key, suffix string) (string, error) {
+	if suffix == "" || strings.ContainsAny(suffix, `/\`) || strings.Contains(suffix, "..") {
+		return "", fmt.Errorf("invalid derived file suffix %q", suffix)
+	}
+	return key + suffix, nil
+}
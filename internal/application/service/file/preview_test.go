@@ -0,0 +1,73 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+func TestGeneratePreviewScalesImage(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 1200, 600))
+	for y := 0; y < 600; y++ {
+		for x := 0; x < 1200; x++ {
+			src.Set(x, y, color.NRGBA{R: 200, A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	preview, err := GeneratePreview(context.Background(), "PNG", buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := jpeg.Decode(bytes.NewReader(preview))
+	if err != nil {
+		t.Fatalf("preview is not a JPEG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != 320 || b.Dy() != 160 {
+		t.Fatalf("preview size = %dx%d, want 320x160", b.Dx(), b.Dy())
+	}
+	if r, _, _, _ := img.At(10, 10).RGBA(); r>>8 < 190 {
+		t.Fatalf("preview lost the image colour, red = %d", r>>8)
+	}
+
+	if _, err := GeneratePreview(context.Background(), "docx", buf.Bytes()); !errors.Is(err, ErrPreviewUnsupported) {
+		t.Fatalf("expected ErrPreviewUnsupported for docx, got %v", err)
+	}
+}
+
+func TestLocalSaveDerivedFile(t *testing.T) {
+	base := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(base, "1", "k1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	svc := NewLocalFileService(base, "")
+
+	got, err := SavePreview(context.Background(), svc, "local://1/k1/a.png", []byte("thumb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "local://1/k1/a.png.thumb.jpg" {
+		t.Fatalf("preview path = %q", got)
+	}
+	data, err := os.ReadFile(filepath.Join(base, "1", "k1", "a.png.thumb.jpg"))
+	if err != nil || string(data) != "thumb" {
+		t.Fatalf("preview not written next to the file: %q, %v", data, err)
+	}
+
+	w := svc.(interfaces.DerivedFileWriter)
+	if _, err := w.SaveDerivedFile(context.Background(), "local://1/k1/a.png", "/../../x", nil); err == nil {
+		t.Fatal("expected a suffix leaving the directory to be rejected")
+	}
+}
@@ -0,0 +1,65 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidRange is returned by GetFileRange when the requested range does
+// not overlap the object, i.e. offset is at or past its end.
+var ErrInvalidRange = errors.New("file: requested range not satisfiable")
+
+// rangeHeader builds the HTTP Range header value for GetFileRange's offset
+// and length; length <= 0 means "to the end of the object".
+func rangeHeader(offset, length int64) (string, error) {
+	if offset < 0 {
+		return "", fmt.Errorf("invalid range offset %d", offset)
+	}
+	if length <= 0 {
+		return fmt.Sprintf("bytes=%d-", offset), nil
+	}
+	return fmt.Sprintf("bytes=%d-%d", offset, offset+length-1), nil
+}
+
+// contentRangeSize extracts the complete object size from a Content-Range
+// response header ("bytes 0-99/1234"). It returns -1 when the size is
+// unknown ("*") or the header is missing.
+func contentRangeSize(contentRange string) int64 {
+	idx := strings.LastIndex(contentRange, "/")
+	if idx < 0 {
+		return -1
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(contentRange[idx+1:]), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return size
+}
+
+// wrapRangeError maps a backend's "416 Range Not Satisfiable" reply to
+// ErrInvalidRange so callers can answer 416 without knowing the SDK.
+func wrapRangeError(backend string, err error) error {
+	var coded interface{ ErrorCode() string }
+	if errors.As(err, &coded) && coded.ErrorCode() == "InvalidRange" {
+		return ErrInvalidRange
+	}
+	var status interface{ HTTPStatusCode() int }
+	if errors.As(err, &status) && status.HTTPStatusCode() == http.StatusRequestedRangeNotSatisfiable {
+		return ErrInvalidRange
+	}
+	if strings.Contains(err.Error(), "InvalidRange") {
+		return ErrInvalidRange
+	}
+	return fmt.Errorf("failed to get file range from %s: %w", backend, err)
+}
+
+// sectionReadCloser limits a seeked file to the requested section while
+// still closing the underlying file.
+type sectionReadCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -27,10 +27,30 @@ type s3FileService struct {
 	client     *s3.Client
 	bucketName string
 	pathPrefix string
+	multipart  multipartConfig
+	sse        serverSideEncryption
+	urls       urlOptions
+}
+
+// presignUploadExpiry bounds presigned PUT URLs, which grant write access and
+// are kept shorter-lived than download URLs.
+const presignUploadExpiry = time.Hour
+
+// s3UsePathStyle reports whether requests address the bucket as
+// endpoint/bucket/key instead of bucket.endpoint/key. S3-compatible services
+// (non-AWS endpoints) default to path-style; forcePathStyle turns it on for
+// AWS endpoints too, e.g. for bucket names containing dots.
+func s3UsePathStyle(endpoint string, forcePathStyle bool) bool {
+	if forcePathStyle {
+		return true
+	}
+	return endpoint != "" && !strings.Contains(endpoint, "amazonaws.com")
 }
 
 // newS3Client creates a bare s3FileService with just the SDK client initialised.
-func newS3Client(endpoint, accessKey, secretKey, bucketName, region, pathPrefix string) (*s3FileService, error) {
+func newS3Client(endpoint, accessKey, secretKey, bucketName, region, pathPrefix string,
+	forcePathStyle bool,
+) (*s3FileService, error) {
 	var cfg aws.Config
 	var err error
 
@@ -45,19 +65,13 @@ func newS3Client(endpoint, accessKey, secretKey, bucketName, region, pathPrefix
 	}
 
 	// Create S3 client with custom endpoint if provided.
-	// For S3-compatible services (non-AWS), use path-style addressing
-	// (endpoint/bucket/key) instead of virtual-hosted style (bucket.endpoint/key).
-	var client *s3.Client
-	if endpoint != "" {
-		usePathStyle := !strings.Contains(endpoint, "amazonaws.com")
-		client = s3.NewFromConfig(cfg, func(o *s3.Options) {
+	usePathStyle := s3UsePathStyle(endpoint, forcePathStyle)
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint != "" {
 			o.BaseEndpoint = aws.String(endpoint)
-			o.UsePathStyle = usePathStyle
-		})
-	} else {
-		// Standard AWS S3
-		client = s3.NewFromConfig(cfg)
-	}
+		}
+		o.UsePathStyle = usePathStyle
+	})
 
 	// Normalize pathPrefix: ensure it ends with '/' if not empty
 	if pathPrefix != "" && !strings.HasSuffix(pathPrefix, "/") {
@@ -68,15 +82,17 @@ func newS3Client(endpoint, accessKey, secretKey, bucketName, region, pathPrefix
 		client:     client,
 		bucketName: bucketName,
 		pathPrefix: pathPrefix,
+		multipart:  loadMultipartConfig(),
+		urls:       loadURLOptions(),
 	}, nil
 }
 
-// NewS3FileService creates an AWS S3 file service.
+// NewS3FileService creates an AWS S3 or S3-compatible file service.
 // It verifies that the bucket exists and creates it if missing.
 func NewS3FileService(endpoint,
-	accessKey, secretKey, bucketName, region, pathPrefix string,
+	accessKey, secretKey, bucketName, region, pathPrefix string, forcePathStyle bool,
 ) (interfaces.FileService, error) {
-	svc, err := newS3Client(endpoint, accessKey, secretKey, bucketName, region, pathPrefix)
+	svc, err := newS3Client(endpoint, accessKey, secretKey, bucketName, region, pathPrefix, forcePathStyle)
 	if err != nil {
 		return nil, err
 	}
@@ -91,6 +107,7 @@ func NewS3FileService(endpoint,
 		if err = svc.createBucket(context.Background()); err != nil {
 			return nil, fmt.Errorf("failed to create bucket: %w", err)
 		}
+		putS3TempLifecycle(context.Background(), svc.client, bucketName, tempKeyPrefix(svc.pathPrefix))
 	}
 
 	return svc, nil
@@ -120,6 +137,56 @@ func (s *s3FileService) createBucket(ctx context.Context) error {
 	return err
 }
 
+// putS3TempLifecycle sets an expiration rule for temp objects under prefix
+// on a newly created bucket. Failures are only logged: not every
+// S3-compatible service implements lifecycle rules, and the cleanup job
+// removes expired temp files either way.
+func putS3TempLifecycle(ctx context.Context, client *s3.Client, bucketName, prefix string) {
+	_, err := client.PutBucketLifecycleConfiguration(ctx, &s3.PutBucketLifecycleConfigurationInput{
+		Bucket: aws.String(bucketName),
+		LifecycleConfiguration: &types.BucketLifecycleConfiguration{
+			Rules: []types.LifecycleRule{{
+				ID:         aws.String(tempLifecycleRuleID),
+				Status:     types.ExpirationStatusEnabled,
+				Filter:     &types.LifecycleRuleFilter{Prefix: aws.String(prefix)},
+				Expiration: &types.LifecycleExpiration{Days: aws.Int32(int32(tempLifecycleDays()))},
+			}},
+		},
+	})
+	if err != nil {
+		logger.Warnf(ctx, "Failed to set temp file lifecycle rule on bucket %s: %v", bucketName, err)
+	}
+}
+
+// deleteS3ObjectsBefore deletes the objects under prefix last modified
+// before olderThan and returns how many were deleted.
+func deleteS3ObjectsBefore(ctx context.Context, client *s3.Client, bucketName, prefix string, olderThan time.Time) (int, error) {
+	deleted := 0
+	paginator := s3.NewListObjectsV2Paginator(client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(bucketName),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to list objects under %s: %w", prefix, err)
+		}
+		for _, obj := range page.Contents {
+			if obj.LastModified == nil || !obj.LastModified.Before(olderThan) {
+				continue
+			}
+			if _, err := client.DeleteObject(ctx, &s3.DeleteObjectInput{
+				Bucket: aws.String(bucketName),
+				Key:    obj.Key,
+			}); err != nil {
+				return deleted, fmt.Errorf("failed to delete %s: %w", aws.ToString(obj.Key), err)
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
 // CheckConnectivity verifies S3 is reachable and, if a bucket is configured,
 // that the bucket exists. This is a read-only probe — it never creates a bucket.
 func (s *s3FileService) CheckConnectivity(ctx context.Context) error {
@@ -144,8 +211,10 @@ func (s *s3FileService) CheckConnectivity(ctx context.Context) error {
 
 // CheckS3Connectivity tests S3 connectivity using the provided credentials.
 // It creates a temporary service instance internally and delegates to CheckConnectivity.
-func CheckS3Connectivity(ctx context.Context, endpoint, accessKey, secretKey, bucketName, region string) error {
-	svc, err := newS3Client(endpoint, accessKey, secretKey, bucketName, region, "")
+func CheckS3Connectivity(ctx context.Context,
+	endpoint, accessKey, secretKey, bucketName, region string, forcePathStyle bool,
+) error {
+	svc, err := newS3Client(endpoint, accessKey, secretKey, bucketName, region, "", forcePathStyle)
 	if err != nil {
 		return err
 	}
@@ -194,13 +263,27 @@ func (s *s3FileService) SaveFile(ctx context.Context,
 		contentType = utils.GetContentTypeByExt(ext)
 	}
 
+	// Large files go up in parts so a failure only costs the parts in flight
+	if s.multipart.useMultipart(file.Size) {
+		resumeKey := uploadResumeKey("s3://"+s.bucketName, tenantID, file.Filename, file.Size)
+		backend := s3MultipartBackend{client: s.client, bucketName: s.bucketName, sse: s.sse}
+		objectName, err = multipartUpload(ctx, backend, s.multipart, resumeKey, objectName, contentType, src, file.Size)
+		if err != nil {
+			return "", fmt.Errorf("failed to upload file to S3: %w", err)
+		}
+		return fmt.Sprintf("s3://%s/%s", s.bucketName, objectName), nil
+	}
+
 	// Upload file to S3
+	sseAlgorithm, kmsKeyID := s.sse.s3Params()
 	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:        aws.String(s.bucketName),
-		Key:           aws.String(objectName),
-		Body:          src,
-		ContentLength: aws.Int64(file.Size),
-		ContentType:   aws.String(contentType),
+		Bucket:               aws.String(s.bucketName),
+		Key:                  aws.String(objectName),
+		Body:                 src,
+		ContentLength:        aws.Int64(file.Size),
+		ContentType:          aws.String(contentType),
+		ServerSideEncryption: sseAlgorithm,
+		SSEKMSKeyId:          kmsKeyID,
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to upload file to S3: %w", err)
@@ -209,6 +292,97 @@ func (s *s3FileService) SaveFile(ctx context.Context,
 	return fmt.Sprintf("s3://%s/%s", s.bucketName, objectName), nil
 }
 
+// s3MultipartBackend implements multipartBackend for any S3-compatible
+// bucket reached through the AWS SDK (S3 and OBS).
+type s3MultipartBackend struct {
+	client     *s3.Client
+	bucketName string
+	sse        serverSideEncryption
+}
+
+func (s s3MultipartBackend) createMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	sseAlgorithm, kmsKeyID := s.sse.s3Params()
+	out, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:               aws.String(s.bucketName),
+		Key:                  aws.String(key),
+		ContentType:          aws.String(contentType),
+		ServerSideEncryption: sseAlgorithm,
+		SSEKMSKeyId:          kmsKeyID,
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.UploadId), nil
+}
+
+func (s s3MultipartBackend) uploadPart(ctx context.Context,
+	key, uploadID string, number int, body io.Reader, size int64,
+) (string, error) {
+	out, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        aws.String(s.bucketName),
+		Key:           aws.String(key),
+		UploadId:      aws.String(uploadID),
+		PartNumber:    aws.Int32(int32(number)),
+		Body:          body,
+		ContentLength: aws.Int64(size),
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.ETag), nil
+}
+
+func (s s3MultipartBackend) listParts(ctx context.Context, key, uploadID string) ([]uploadedPart, error) {
+	var parts []uploadedPart
+	input := &s3.ListPartsInput{
+		Bucket:   aws.String(s.bucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	}
+	for {
+		out, err := s.client.ListParts(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range out.Parts {
+			parts = append(parts, uploadedPart{
+				number: int(aws.ToInt32(p.PartNumber)),
+				etag:   aws.ToString(p.ETag),
+				size:   aws.ToInt64(p.Size),
+			})
+		}
+		if !aws.ToBool(out.IsTruncated) {
+			return parts, nil
+		}
+		input.PartNumberMarker = out.NextPartNumberMarker
+	}
+}
+
+func (s s3MultipartBackend) completeMultipartUpload(ctx context.Context,
+	key, uploadID string, parts []uploadedPart,
+) error {
+	completed := make([]types.CompletedPart, len(parts))
+	for i, p := range parts {
+		completed[i] = types.CompletedPart{ETag: aws.String(p.etag), PartNumber: aws.Int32(int32(p.number))}
+	}
+	_, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucketName),
+		Key:             aws.String(key),
+		UploadId:        aws.String(uploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	return err
+}
+
+func (s s3MultipartBackend) abortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	_, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(s.bucketName),
+		Key:      aws.String(key),
+		UploadId: aws.String(uploadID),
+	})
+	return err
+}
+
 // GetFile gets a file from S3
 func (s *s3FileService) GetFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
 	objectName, err := s.parseS3FilePath(filePath)
@@ -227,6 +401,31 @@ func (s *s3FileService) GetFile(ctx context.Context, filePath string) (io.ReadCl
 	return resp.Body, nil
 }
 
+// GetFileRange gets length bytes of a file starting at offset
+func (s *s3FileService) GetFileRange(ctx context.Context,
+	filePath string, offset, length int64,
+) (io.ReadCloser, int64, error) {
+	objectName, err := s.parseS3FilePath(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	byteRange, err := rangeHeader(offset, length)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(objectName),
+		Range:  aws.String(byteRange),
+	})
+	if err != nil {
+		return nil, 0, wrapRangeError("S3", err)
+	}
+
+	return resp.Body, contentRangeSize(aws.ToString(resp.ContentRange)), nil
+}
+
 // DeleteFile deletes a file
 func (s *s3FileService) DeleteFile(ctx context.Context, filePath string) error {
 	objectName, err := s.parseS3FilePath(filePath)
@@ -262,10 +461,13 @@ func (s *s3FileService) CopyFile(ctx context.Context,
 	// CopySource is "bucket/key"; the '/' separators must NOT be percent-encoded
 	// (url.PathEscape would turn them into %2F and break the bucket/key split).
 	// srcKey is already validated by parseS3FilePath -> SafeObjectKey.
+	sseAlgorithm, kmsKeyID := s.sse.s3Params()
 	_, err = s.client.CopyObject(ctx, &s3.CopyObjectInput{
-		Bucket:     aws.String(s.bucketName),
-		CopySource: aws.String(s.bucketName + "/" + srcKey),
-		Key:        aws.String(destKey),
+		Bucket:               aws.String(s.bucketName),
+		CopySource:           aws.String(s.bucketName + "/" + srcKey),
+		Key:                  aws.String(destKey),
+		ServerSideEncryption: sseAlgorithm,
+		SSEKMSKeyId:          kmsKeyID,
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to copy file in S3: %w", err)
@@ -277,7 +479,7 @@ func (s *s3FileService) CopyFile(ctx context.Context,
 }
 
 // SaveBytes saves bytes data to S3 and returns the file path
-// temp parameter is ignored for S3 (no auto-expiration support in this implementation)
+// Temp files go under the temp/ prefix, where they expire; see temp.go.
 func (s *s3FileService) SaveBytes(ctx context.Context, data []byte, tenantID uint64, fileName string, temp bool) (string, error) {
 	safeName, err := utils.SafeFileName(fileName)
 	if err != nil {
@@ -285,15 +487,21 @@ func (s *s3FileService) SaveBytes(ctx context.Context, data []byte, tenantID uin
 	}
 	ext := filepath.Ext(safeName)
 	objectName := fmt.Sprintf("%s%d/exports/%s%s", s.pathPrefix, tenantID, uuid.New().String(), ext)
+	if temp {
+		objectName = tempObjectKey(s.pathPrefix, tenantID, ext)
+	}
 
 	// Upload bytes to S3
 	reader := bytes.NewReader(data)
+	sseAlgorithm, kmsKeyID := s.sse.s3Params()
 	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket:        aws.String(s.bucketName),
-		Key:           aws.String(objectName),
-		Body:          reader,
-		ContentLength: aws.Int64(int64(len(data))),
-		ContentType:   aws.String(utils.GetContentTypeByExt(ext)),
+		Bucket:               aws.String(s.bucketName),
+		Key:                  aws.String(objectName),
+		Body:                 reader,
+		ContentLength:        aws.Int64(int64(len(data))),
+		ContentType:          aws.String(utils.GetContentTypeByExt(ext)),
+		ServerSideEncryption: sseAlgorithm,
+		SSEKMSKeyId:          kmsKeyID,
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to upload bytes to S3: %w", err)
@@ -302,8 +510,42 @@ func (s *s3FileService) SaveBytes(ctx context.Context, data []byte, tenantID uin
 	return fmt.Sprintf("s3://%s/%s", s.bucketName, objectName), nil
 }
 
+// SaveDerivedFile stores data next to the object at filePath, under the
+// same server-side encryption.
+func (s *s3FileService) SaveDerivedFile(ctx context.Context, filePath, suffix string, data []byte) (string, error) {
+	objectName, err := s.parseS3FilePath(filePath)
+	if err != nil {
+		return "", err
+	}
+	key, err := derivedObjectKey(objectName, suffix)
+	if err != nil {
+		return "", err
+	}
+	sseAlgorithm, kmsKeyID := s.sse.s3Params()
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:               aws.String(s.bucketName),
+		Key:                  aws.String(key),
+		Body:                 bytes.NewReader(data),
+		ContentLength:        aws.Int64(int64(len(data))),
+		ContentType:          aws.String(utils.GetContentTypeByExt(filepath.Ext(suffix))),
+		ServerSideEncryption: sseAlgorithm,
+		SSEKMSKeyId:          kmsKeyID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload derived file to S3: %w", err)
+	}
+	return filePath + suffix, nil
+}
+
 // GetFileURL returns a presigned download URL for the file
 func (s *s3FileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
+	return s.GetFileURLWithExpiry(ctx, filePath, 0)
+}
+
+// GetFileURLWithExpiry returns a presigned download URL valid for expiry,
+// or for the configured default when expiry <= 0.
+func (s *s3FileService) GetFileURLWithExpiry(ctx context.Context, filePath string, expiry time.Duration) (string, error) {
+	ttl := s.urls.expiryFor(expiry, defaultURLExpiry)
 	objectName, err := s.parseS3FilePath(filePath)
 	if err != nil {
 		return "", err
@@ -316,10 +558,96 @@ func (s *s3FileService) GetFileURL(ctx context.Context, filePath string) (string
 	presignedReq, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: aws.String(s.bucketName),
 		Key:    aws.String(objectName),
-	}, s3.WithPresignExpires(24*time.Hour))
+	}, s3.WithPresignExpires(ttl))
 	if err != nil {
 		return "", fmt.Errorf("failed to generate presigned URL: %w", err)
 	}
 
+	return s.urls.rewrite(presignedReq.URL), nil
+}
+
+func (s *s3FileService) setURLOptions(o urlOptions) {
+	s.urls = s.urls.merge(o)
+}
+
+// GetUploadURL returns a presigned PUT URL for the object at filePath, so a
+// client can upload directly to the bucket. The URL is valid for
+// presignUploadExpiry; when contentType is set the upload must send the same
+// Content-Type header. With server-side encryption on, the upload must also
+// send the matching x-amz-server-side-encryption headers.
+func (s *s3FileService) GetUploadURL(ctx context.Context, filePath, contentType string) (string, error) {
+	objectName, err := s.parseS3FilePath(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	sseAlgorithm, kmsKeyID := s.sse.s3Params()
+	input := &s3.PutObjectInput{
+		Bucket:               aws.String(s.bucketName),
+		Key:                  aws.String(objectName),
+		ServerSideEncryption: sseAlgorithm,
+		SSEKMSKeyId:          kmsKeyID,
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	presignedReq, err := s3.NewPresignClient(s.client).PresignPutObject(ctx, input,
+		s3.WithPresignExpires(presignUploadExpiry))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate presigned upload URL: %w", err)
+	}
+
 	return presignedReq.URL, nil
 }
+
+// s3Params returns the SSE request parameters of S3 writes; both are empty
+// when encryption is off, which leaves the headers out.
+func (e serverSideEncryption) s3Params() (types.ServerSideEncryption, *string) {
+	switch {
+	case e.kms():
+		return types.ServerSideEncryptionAwsKms, aws.String(e.kmsKeyID)
+	case e.enabled():
+		return types.ServerSideEncryptionAes256, nil
+	default:
+		return "", nil
+	}
+}
+
+func (s *s3FileService) setEncryption(sse serverSideEncryption) {
+	s.sse = sse
+}
+
+// EncryptionKeyID returns the key new objects are encrypted with; see
+// interfaces.EncryptedFileService.
+func (s *s3FileService) EncryptionKeyID() string {
+	return s.sse.keyID()
+}
+
+// ReencryptFile rewrites the object in place with a server-side copy under
+// the current encryption settings, keeping its metadata.
+func (s *s3FileService) ReencryptFile(ctx context.Context, filePath string) error {
+	objectName, err := s.parseS3FilePath(filePath)
+	if err != nil {
+		return err
+	}
+
+	sseAlgorithm, kmsKeyID := s.sse.s3Params()
+	_, err = s.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:               aws.String(s.bucketName),
+		CopySource:           aws.String(s.bucketName + "/" + objectName),
+		Key:                  aws.String(objectName),
+		MetadataDirective:    types.MetadataDirectiveCopy,
+		ServerSideEncryption: sseAlgorithm,
+		SSEKMSKeyId:          kmsKeyID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to re-encrypt file in S3: %w", err)
+	}
+	return nil
+}
+
+// CleanupTempFiles deletes tenantID's temp files last modified before
+// olderThan; see interfaces.TempFileCleaner.
+func (s *s3FileService) CleanupTempFiles(ctx context.Context, tenantID uint64, olderThan time.Time) (int, error) {
+	return deleteS3ObjectsBefore(ctx, s.client, s.bucketName, tempTenantPrefix(s.pathPrefix, tenantID), olderThan)
+}
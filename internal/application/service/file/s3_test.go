@@ -1,7 +1,6 @@
 package file
 
 import (
-	"strings"
 	"testing"
 )
 
@@ -9,6 +8,7 @@ func TestNewS3Client_PathStyleForCompatibleEndpoints(t *testing.T) {
 	tests := []struct {
 		name          string
 		endpoint      string
+		force         bool
 		wantPathStyle bool
 	}{
 		{
@@ -31,12 +31,22 @@ func TestNewS3Client_PathStyleForCompatibleEndpoints(t *testing.T) {
 			endpoint:      "https://s3.cn-north-1.amazonaws.com.cn",
 			wantPathStyle: false,
 		},
+		{
+			name:          "forced path-style applies to AWS endpoints",
+			endpoint:      "https://s3.us-east-1.amazonaws.com",
+			force:         true,
+			wantPathStyle: true,
+		},
+		{
+			name:          "default AWS endpoint uses virtual-hosted",
+			endpoint:      "",
+			wantPathStyle: false,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			// Verify the path-style detection logic matches our implementation
-			usePathStyle := !strings.Contains(tt.endpoint, "amazonaws.com")
+			usePathStyle := s3UsePathStyle(tt.endpoint, tt.force)
 			if usePathStyle != tt.wantPathStyle {
 				t.Errorf("endpoint %q: usePathStyle = %v, want %v", tt.endpoint, usePathStyle, tt.wantPathStyle)
 			}
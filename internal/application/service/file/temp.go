@@ -0,0 +1,54 @@
+package file
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Temp files (SaveBytes with temp=true) are written under
+// <pathPrefix>temp/<tenantID>/ so bucket lifecycle rules and the periodic
+// cleanup job can find them by prefix.
+const (
+	envTempRetentionHours = "TEMP_FILE_RETENTION_HOURS"
+	defaultTempRetention  = 24 * time.Hour
+	tempDirName           = "temp"
+	// tempLifecycleRuleID names the expiration rule set on buckets this
+	// service creates.
+	tempLifecycleRuleID = "weknora-temp-expiration"
+)
+
+// TempFileRetention returns how long temp files are kept when a tenant does
+// not configure its own retention: TEMP_FILE_RETENTION_HOURS, or 24 hours.
+func TempFileRetention() time.Duration {
+	if h, err := strconv.Atoi(strings.TrimSpace(os.Getenv(envTempRetentionHours))); err == nil && h > 0 {
+		return time.Duration(h) * time.Hour
+	}
+	return defaultTempRetention
+}
+
+// tempLifecycleDays converts TempFileRetention into the whole days bucket
+// expiration rules are expressed in, rounding up.
+func tempLifecycleDays() int {
+	return int((TempFileRetention() + 24*time.Hour - 1) / (24 * time.Hour))
+}
+
+// tempKeyPrefix is the key prefix of every temp object. pathPrefix is empty
+// or ends with "/".
+func tempKeyPrefix(pathPrefix string) string {
+	return pathPrefix + tempDirName + "/"
+}
+
+// tempTenantPrefix is the key prefix of tenantID's temp objects.
+func tempTenantPrefix(pathPrefix string, tenantID uint64) string {
+	return fmt.Sprintf("%s%d/", tempKeyPrefix(pathPrefix), tenantID)
+}
+
+// tempObjectKey returns a fresh key for a temp object with extension ext.
+func tempObjectKey(pathPrefix string, tenantID uint64, ext string) string {
+	return tempTenantPrefix(pathPrefix, tenantID) + uuid.New().String() + ext
+}
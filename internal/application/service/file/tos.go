@@ -25,6 +25,9 @@ type tosFileService struct {
 	pathPrefix     string
 	bucketName     string
 	tempBucketName string
+	multipart      multipartConfig
+	sse            serverSideEncryption
+	urls           urlOptions
 }
 
 const tosScheme = "tos://"
@@ -72,6 +75,8 @@ func NewTosFileServiceWithTempBucket(endpoint, region, accessKey, secretKey, buc
 		pathPrefix:     strings.Trim(pathPrefix, "/"),
 		bucketName:     bucketName,
 		tempBucketName: tempBucketName,
+		multipart:      loadMultipartConfig(),
+		urls:           loadURLOptions(),
 	}, nil
 }
 
@@ -171,11 +176,22 @@ func (s *tosFileService) SaveFile(ctx context.Context, file *multipart.FileHeade
 	if contentType == "" {
 		contentType = utils.GetContentTypeByExt(ext)
 	}
+	if s.multipart.useMultipart(file.Size) {
+		resumeKey := uploadResumeKey(tosScheme+s.bucketName, tenantID, file.Filename, file.Size)
+		objectName, err = multipartUpload(ctx, s, s.multipart, resumeKey, objectName, contentType, src, file.Size)
+		if err != nil {
+			return "", fmt.Errorf("failed to upload file to TOS: %w", err)
+		}
+		return fmt.Sprintf("tos://%s/%s", s.bucketName, objectName), nil
+	}
+	sseAlgorithm, kmsKeyID := s.sse.tosParams()
 	_, err = s.client.PutObjectV2(ctx, &tos.PutObjectV2Input{
 		PutObjectBasicInput: tos.PutObjectBasicInput{
-			Bucket:      s.bucketName,
-			Key:         objectName,
-			ContentType: contentType,
+			Bucket:                    s.bucketName,
+			Key:                       objectName,
+			ContentType:               contentType,
+			ServerSideEncryption:      sseAlgorithm,
+			ServerSideEncryptionKeyID: kmsKeyID,
 		},
 		Content: src,
 	})
@@ -186,6 +202,84 @@ func (s *tosFileService) SaveFile(ctx context.Context, file *multipart.FileHeade
 	return fmt.Sprintf("tos://%s/%s", s.bucketName, objectName), nil
 }
 
+// createMultipartUpload and the methods below implement multipartBackend.
+func (s *tosFileService) createMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	sseAlgorithm, kmsKeyID := s.sse.tosParams()
+	out, err := s.client.CreateMultipartUploadV2(ctx, &tos.CreateMultipartUploadV2Input{
+		Bucket:                    s.bucketName,
+		Key:                       key,
+		ContentType:               contentType,
+		ServerSideEncryption:      sseAlgorithm,
+		ServerSideEncryptionKeyID: kmsKeyID,
+	})
+	if err != nil {
+		return "", err
+	}
+	return out.UploadID, nil
+}
+
+func (s *tosFileService) uploadPart(ctx context.Context,
+	key, uploadID string, number int, body io.Reader, size int64,
+) (string, error) {
+	out, err := s.client.UploadPartV2(ctx, &tos.UploadPartV2Input{
+		UploadPartBasicInput: tos.UploadPartBasicInput{
+			Bucket:     s.bucketName,
+			Key:        key,
+			UploadID:   uploadID,
+			PartNumber: number,
+		},
+		Content:       body,
+		ContentLength: size,
+	})
+	if err != nil {
+		return "", err
+	}
+	return out.ETag, nil
+}
+
+func (s *tosFileService) listParts(ctx context.Context, key, uploadID string) ([]uploadedPart, error) {
+	var parts []uploadedPart
+	input := &tos.ListPartsInput{Bucket: s.bucketName, Key: key, UploadID: uploadID}
+	for {
+		out, err := s.client.ListParts(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range out.Parts {
+			parts = append(parts, uploadedPart{number: p.PartNumber, etag: p.ETag, size: p.Size})
+		}
+		if !out.IsTruncated {
+			return parts, nil
+		}
+		input.PartNumberMarker = out.NextPartNumberMarker
+	}
+}
+
+func (s *tosFileService) completeMultipartUpload(ctx context.Context,
+	key, uploadID string, parts []uploadedPart,
+) error {
+	completed := make([]tos.UploadedPartV2, len(parts))
+	for i, p := range parts {
+		completed[i] = tos.UploadedPartV2{PartNumber: p.number, ETag: p.etag}
+	}
+	_, err := s.client.CompleteMultipartUploadV2(ctx, &tos.CompleteMultipartUploadV2Input{
+		Bucket:   s.bucketName,
+		Key:      key,
+		UploadID: uploadID,
+		Parts:    completed,
+	})
+	return err
+}
+
+func (s *tosFileService) abortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	_, err := s.client.AbortMultipartUpload(ctx, &tos.AbortMultipartUploadInput{
+		Bucket:   s.bucketName,
+		Key:      key,
+		UploadID: uploadID,
+	})
+	return err
+}
+
 func (s *tosFileService) SaveBytes(ctx context.Context, data []byte, tenantID uint64, fileName string, temp bool) (string, error) {
 	safeName, err := utils.SafeFileName(fileName)
 	if err != nil {
@@ -211,11 +305,14 @@ func (s *tosFileService) SaveBytes(ctx context.Context, data []byte, tenantID ui
 		)
 	}
 
+	sseAlgorithm, kmsKeyID := s.sse.tosParams()
 	_, err = s.client.PutObjectV2(ctx, &tos.PutObjectV2Input{
 		PutObjectBasicInput: tos.PutObjectBasicInput{
-			Bucket:      targetBucket,
-			Key:         objectName,
-			ContentType: utils.GetContentTypeByExt(ext),
+			Bucket:                    targetBucket,
+			Key:                       objectName,
+			ContentType:               utils.GetContentTypeByExt(ext),
+			ServerSideEncryption:      sseAlgorithm,
+			ServerSideEncryptionKeyID: kmsKeyID,
 		},
 		Content: reader,
 	})
@@ -226,6 +323,37 @@ func (s *tosFileService) SaveBytes(ctx context.Context, data []byte, tenantID ui
 	return fmt.Sprintf("tos://%s/%s", targetBucket, objectName), nil
 }
 
+// SaveDerivedFile stores data next to the object at filePath, under the
+// same server-side encryption.
+func (s *tosFileService) SaveDerivedFile(ctx context.Context, filePath, suffix string, data []byte) (string, error) {
+	bucketName, objectName, err := parseTOSFilePath(filePath)
+	if err != nil {
+		return "", err
+	}
+	if err := utils.SafeObjectKey(objectName); err != nil {
+		return "", fmt.Errorf("invalid file path: %w", err)
+	}
+	key, err := derivedObjectKey(objectName, suffix)
+	if err != nil {
+		return "", err
+	}
+	sseAlgorithm, kmsKeyID := s.sse.tosParams()
+	_, err = s.client.PutObjectV2(ctx, &tos.PutObjectV2Input{
+		PutObjectBasicInput: tos.PutObjectBasicInput{
+			Bucket:                    bucketName,
+			Key:                       key,
+			ContentType:               utils.GetContentTypeByExt(filepath.Ext(suffix)),
+			ServerSideEncryption:      sseAlgorithm,
+			ServerSideEncryptionKeyID: kmsKeyID,
+		},
+		Content: bytes.NewReader(data),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload derived file to TOS: %w", err)
+	}
+	return filePath + suffix, nil
+}
+
 // CopyFile copies an existing TOS object to a new knowledge-owned object using a
 // server-side CopyObject (no data leaves TOS). The destination uses the same
 // layout as SaveFile. Returns ErrCrossBackendCopy when srcPath is not a tos:// path.
@@ -248,11 +376,14 @@ func (s *tosFileService) CopyFile(ctx context.Context,
 		uuid.New().String()+ext,
 	)
 
+	sseAlgorithm, kmsKeyID := s.sse.tosParams()
 	_, err = s.client.CopyObject(ctx, &tos.CopyObjectInput{
-		Bucket:    s.bucketName,
-		Key:       destKey,
-		SrcBucket: srcBucket,
-		SrcKey:    srcKey,
+		Bucket:                    s.bucketName,
+		Key:                       destKey,
+		SrcBucket:                 srcBucket,
+		SrcKey:                    srcKey,
+		ServerSideEncryption:      sseAlgorithm,
+		ServerSideEncryptionKeyID: kmsKeyID,
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to copy file in TOS: %w", err)
@@ -282,6 +413,33 @@ func (s *tosFileService) GetFile(ctx context.Context, filePath string) (io.ReadC
 	return output.Content, nil
 }
 
+// GetFileRange gets length bytes of a file from TOS starting at offset
+func (s *tosFileService) GetFileRange(ctx context.Context,
+	filePath string, offset, length int64,
+) (io.ReadCloser, int64, error) {
+	bucketName, objectName, err := parseTOSFilePath(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := utils.SafeObjectKey(objectName); err != nil {
+		return nil, 0, fmt.Errorf("invalid file path: %w", err)
+	}
+	byteRange, err := rangeHeader(offset, length)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	output, err := s.client.GetObjectV2(ctx, &tos.GetObjectV2Input{
+		Bucket: bucketName,
+		Key:    objectName,
+		Range:  byteRange,
+	})
+	if err != nil {
+		return nil, 0, wrapRangeError("TOS", err)
+	}
+	return output.Content, contentRangeSize(output.ContentRange), nil
+}
+
 func (s *tosFileService) DeleteFile(ctx context.Context, filePath string) error {
 	bucketName, objectName, err := parseTOSFilePath(filePath)
 	if err != nil {
@@ -301,7 +459,15 @@ func (s *tosFileService) DeleteFile(ctx context.Context, filePath string) error
 	return nil
 }
 
+// GetFileURL returns a presigned download URL for the file.
 func (s *tosFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
+	return s.GetFileURLWithExpiry(ctx, filePath, 0)
+}
+
+// GetFileURLWithExpiry returns a presigned download URL valid for expiry,
+// or for the configured default when expiry <= 0.
+func (s *tosFileService) GetFileURLWithExpiry(ctx context.Context, filePath string, expiry time.Duration) (string, error) {
+	ttl := s.urls.expiryFor(expiry, defaultURLExpiry)
 	bucketName, objectName, err := parseTOSFilePath(filePath)
 	if err != nil {
 		return "", err
@@ -314,10 +480,65 @@ func (s *tosFileService) GetFileURL(ctx context.Context, filePath string) (strin
 		HTTPMethod: enum.HttpMethodGet,
 		Bucket:     bucketName,
 		Key:        objectName,
-		Expires:    int64((24 * time.Hour).Seconds()),
+		Expires:    int64(ttl.Seconds()),
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to generate TOS presigned URL: %w", err)
 	}
-	return output.SignedUrl, nil
+	return s.urls.rewrite(output.SignedUrl), nil
+}
+
+func (s *tosFileService) setURLOptions(o urlOptions) {
+	s.urls = s.urls.merge(o)
+}
+
+// tosParams returns the SSE request parameters of TOS writes: AES256 for
+// SSE-TOS and kms with the key for SSE-KMS. Both are empty when encryption
+// is off, which leaves the headers out.
+func (e serverSideEncryption) tosParams() (string, string) {
+	switch {
+	case e.kms():
+		return "kms", e.kmsKeyID
+	case e.enabled():
+		return "AES256", ""
+	default:
+		return "", ""
+	}
+}
+
+func (s *tosFileService) setEncryption(sse serverSideEncryption) {
+	s.sse = sse
+}
+
+// EncryptionKeyID returns the key new objects are encrypted with; see
+// interfaces.EncryptedFileService.
+func (s *tosFileService) EncryptionKeyID() string {
+	return s.sse.keyID()
+}
+
+// ReencryptFile rewrites the object in place with a server-side copy under
+// the current encryption settings.
+func (s *tosFileService) ReencryptFile(ctx context.Context, filePath string) error {
+	bucketName, objectName, err := parseTOSFilePath(filePath)
+	if err != nil {
+		return err
+	}
+	if err := utils.SafeObjectKey(objectName); err != nil {
+		return fmt.Errorf("invalid file path: %w", err)
+	}
+
+	sseAlgorithm, kmsKeyID := s.sse.tosParams()
+	_, err = s.client.CopyObject(ctx, &tos.CopyObjectInput{
+		Bucket:                    bucketName,
+		Key:                       objectName,
+		SrcBucket:                 bucketName,
+		SrcKey:                    objectName,
+		MetadataDirective:         enum.MetadataDirectiveCopy,
+		ServerSideEncryption:      sseAlgorithm,
+		ServerSideEncryptionKeyID: kmsKeyID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to re-encrypt file in TOS: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,130 @@
+package file
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+
+	"github.com/Tencent/WeKnora/internal/tracing/telemetry"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedFileService records the calls of a FileService as OpenTelemetry
+// spans. It only implements FileService; reach the optional interfaces of
+// the service underneath with As.
+type tracedFileService struct {
+	inner    interfaces.FileService
+	provider string
+}
+
+// WithTracing wraps svc so its calls are traced, when OpenTelemetry tracing
+// is on. provider labels the spans.
+func WithTracing(svc interfaces.FileService, provider string) interfaces.FileService {
+	if svc == nil || !telemetry.Enabled() {
+		return svc
+	}
+	return &tracedFileService{inner: svc, provider: provider}
+}
+
+// As finds the first service in svc's wrapper chain that implements T, the
+// way errors.As walks wrapped errors. Use it instead of a type assertion to
+// test a file service for an optional interface.
+func As[T any](svc interfaces.FileService) (T, bool) {
+	for svc != nil {
+		if t, ok := svc.(T); ok {
+			return t, true
+		}
+		w, ok := svc.(interface{ Unwrap() interfaces.FileService })
+		if !ok {
+			break
+		}
+		svc = w.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+func (t *tracedFileService) Unwrap() interfaces.FileService { return t.inner }
+
+func (t *tracedFileService) CheckConnectivity(ctx context.Context) error {
+	ctx, span := t.start(ctx, "check_connectivity", "")
+	err := t.inner.CheckConnectivity(ctx)
+	telemetry.End(span, err)
+	return err
+}
+
+func (t *tracedFileService) SaveFile(ctx context.Context,
+	file *multipart.FileHeader, tenantID uint64, knowledgeID string,
+) (string, error) {
+	ctx, span := t.start(ctx, "save", file.Filename, attribute.Int64("file.size", file.Size))
+	path, err := t.inner.SaveFile(ctx, file, tenantID, knowledgeID)
+	telemetry.End(span, err)
+	return path, err
+}
+
+func (t *tracedFileService) SaveBytes(ctx context.Context,
+	data []byte, tenantID uint64, fileName string, temp bool,
+) (string, error) {
+	ctx, span := t.start(ctx, "save_bytes", fileName,
+		attribute.Int("file.size", len(data)), attribute.Bool("file.temp", temp))
+	path, err := t.inner.SaveBytes(ctx, data, tenantID, fileName, temp)
+	telemetry.End(span, err)
+	return path, err
+}
+
+func (t *tracedFileService) GetFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	ctx, span := t.start(ctx, "get", filePath)
+	rc, err := t.inner.GetFile(ctx, filePath)
+	telemetry.End(span, err)
+	return rc, err
+}
+
+func (t *tracedFileService) GetFileRange(ctx context.Context,
+	filePath string, offset, length int64,
+) (io.ReadCloser, int64, error) {
+	ctx, span := t.start(ctx, "get_range", filePath,
+		attribute.Int64("file.range.offset", offset), attribute.Int64("file.range.length", length))
+	rc, size, err := t.inner.GetFileRange(ctx, filePath, offset, length)
+	telemetry.End(span, err)
+	return rc, size, err
+}
+
+func (t *tracedFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
+	ctx, span := t.start(ctx, "get_url", filePath)
+	url, err := t.inner.GetFileURL(ctx, filePath)
+	telemetry.End(span, err)
+	return url, err
+}
+
+func (t *tracedFileService) DeleteFile(ctx context.Context, filePath string) error {
+	ctx, span := t.start(ctx, "delete", filePath)
+	err := t.inner.DeleteFile(ctx, filePath)
+	telemetry.End(span, err)
+	return err
+}
+
+func (t *tracedFileService) CopyFile(ctx context.Context,
+	srcPath string, tenantID uint64, knowledgeID string,
+) (string, error) {
+	ctx, span := t.start(ctx, "copy", srcPath)
+	path, err := t.inner.CopyFile(ctx, srcPath, tenantID, knowledgeID)
+	telemetry.End(span, err)
+	return path, err
+}
+
+// start opens the span of one storage operation. File paths stay out of
+// span names to keep their cardinality low.
+func (t *tracedFileService) start(ctx context.Context, operation, path string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	attrs = append(attrs,
+		attribute.String("storage.provider", t.provider),
+		attribute.String("storage.operation", operation),
+	)
+	if path != "" {
+		attrs = append(attrs, attribute.String("file.path", path))
+	}
+	return telemetry.Start(ctx, "file."+operation, attrs...)
+}
@@ -0,0 +1,47 @@
+package file
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+func TestWithTracingDisabledReturnsService(t *testing.T) {
+	svc := NewDummyFileService()
+	if got := WithTracing(svc, "dummy"); got != svc {
+		t.Fatalf("WithTracing wrapped the service while tracing is off")
+	}
+}
+
+func TestAsFindsOptionalInterfaceBehindTracing(t *testing.T) {
+	dir := t.TempDir()
+	local := NewLocalFileService(dir, "")
+	wrapped := &tracedFileService{inner: local, provider: "local"}
+
+	if _, ok := interfaces.FileService(wrapped).(interfaces.DerivedFileWriter); ok {
+		t.Fatalf("the tracing wrapper must not claim optional interfaces itself")
+	}
+	if _, ok := As[interfaces.DerivedFileWriter](wrapped); !ok {
+		t.Fatalf("As did not unwrap to the local service")
+	}
+	if _, ok := As[interfaces.PresignedUploader](wrapped); ok {
+		t.Fatalf("As found an interface no service in the chain implements")
+	}
+
+	ctx := context.Background()
+	path, err := wrapped.SaveBytes(ctx, []byte("hello"), 1, "a.txt", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc, err := wrapped.GetFile(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, _ := io.ReadAll(rc)
+	if string(data) != "hello" {
+		t.Fatalf("read %q through the wrapper, want %q", data, "hello")
+	}
+}
@@ -0,0 +1,131 @@
+package file
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	envURLExpirySeconds = "FILE_URL_EXPIRY_SECONDS"
+	envURLCDNDomain     = "FILE_URL_CDN_DOMAIN"
+	// defaultURLExpiry is the lifetime of presigned links when neither the
+	// caller, the tenant nor FILE_URL_EXPIRY_SECONDS chooses one.
+	defaultURLExpiry = 24 * time.Hour
+)
+
+// urlOptions shapes the download links a file service hands out.
+type urlOptions struct {
+	// expiry is the default lifetime of presigned links; 0 keeps the
+	// provider's own default.
+	expiry time.Duration
+	// cdnOrigin replaces the scheme and host of links when set.
+	cdnOrigin *url.URL
+}
+
+// loadURLOptions reads the server-wide FILE_URL_* defaults, ignoring unset
+// or invalid values.
+func loadURLOptions() urlOptions {
+	return newURLOptions(&types.FileURLConfig{
+		ExpirySeconds: parseURLExpiry(os.Getenv(envURLExpirySeconds)),
+		CDNDomain:     strings.TrimSpace(os.Getenv(envURLCDNDomain)),
+	})
+}
+
+func parseURLExpiry(v string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || n <= 0 {
+		return 0
+	}
+	return min(n, types.MaxFileURLExpirySeconds)
+}
+
+// newURLOptions converts cfg, dropping settings that fail validation.
+func newURLOptions(cfg *types.FileURLConfig) urlOptions {
+	var o urlOptions
+	if cfg == nil {
+		return o
+	}
+	if cfg.ExpirySeconds > 0 && cfg.ExpirySeconds <= types.MaxFileURLExpirySeconds {
+		o.expiry = time.Duration(cfg.ExpirySeconds) * time.Second
+	}
+	if cfg.CDNDomain != "" && (&types.FileURLConfig{CDNDomain: cfg.CDNDomain}).Validate() == nil {
+		o.cdnOrigin, _ = url.Parse(cfg.CDNDomain)
+	}
+	return o
+}
+
+// merge returns o with the settings of override that are set.
+func (o urlOptions) merge(override urlOptions) urlOptions {
+	if override.expiry > 0 {
+		o.expiry = override.expiry
+	}
+	if override.cdnOrigin != nil {
+		o.cdnOrigin = override.cdnOrigin
+	}
+	return o
+}
+
+// expiryFor picks the lifetime of one link: the caller's, else the
+// configured default, else fallback (the provider's own default).
+func (o urlOptions) expiryFor(requested, fallback time.Duration) time.Duration {
+	switch {
+	case requested > 0:
+		return min(requested, time.Duration(types.MaxFileURLExpirySeconds)*time.Second)
+	case o.expiry > 0:
+		return o.expiry
+	default:
+		return fallback
+	}
+}
+
+// rewrite points link at the CDN origin, keeping path and query (and so
+// the origin's signature) intact. Non-HTTP links are returned unchanged.
+func (o urlOptions) rewrite(link string) string {
+	if o.cdnOrigin == nil {
+		return link
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return link
+	}
+	u.Scheme = o.cdnOrigin.Scheme
+	u.Host = o.cdnOrigin.Host
+	return u.String()
+}
+
+// urlConfigurable is implemented by file services whose download links can
+// be shaped per tenant.
+type urlConfigurable interface {
+	interfaces.ExpiringURLProvider
+	setURLOptions(o urlOptions)
+}
+
+// applyURLConfig layers the tenant's link settings over the server-wide
+// defaults svc was created with.
+func applyURLConfig(svc interfaces.FileService, cfg *types.FileURLConfig) {
+	if cfg == nil {
+		return
+	}
+	if configurable, ok := svc.(urlConfigurable); ok {
+		configurable.setURLOptions(newURLOptions(cfg))
+	}
+}
+
+// GetFileURLWithExpiry returns a download link for filePath valid for
+// expiry, for services that support choosing it, and falls back to
+// GetFileURL otherwise. expiry <= 0 uses the configured default.
+func GetFileURLWithExpiry(ctx context.Context,
+	svc interfaces.FileService, filePath string, expiry time.Duration,
+) (string, error) {
+	if p, ok := As[interfaces.ExpiringURLProvider](svc); ok {
+		return p.GetFileURLWithExpiry(ctx, filePath, expiry)
+	}
+	return svc.GetFileURL(ctx, filePath)
+}
@@ -0,0 +1,78 @@
+package file
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestURLOptionsExpiryFor(t *testing.T) {
+	var o urlOptions
+	if got := o.expiryFor(0, defaultURLExpiry); got != defaultURLExpiry {
+		t.Fatalf("expiryFor without config = %v, want the provider default", got)
+	}
+	o = newURLOptions(&types.FileURLConfig{ExpirySeconds: 600})
+	if got := o.expiryFor(0, defaultURLExpiry); got != 10*time.Minute {
+		t.Fatalf("expiryFor = %v, want the configured 10m", got)
+	}
+	if got := o.expiryFor(time.Minute, defaultURLExpiry); got != time.Minute {
+		t.Fatalf("expiryFor = %v, want the requested 1m", got)
+	}
+	if got := o.expiryFor(30*24*time.Hour, defaultURLExpiry); got != 7*24*time.Hour {
+		t.Fatalf("expiryFor = %v, want it capped at 7 days", got)
+	}
+}
+
+func TestURLOptionsRewrite(t *testing.T) {
+	o := newURLOptions(&types.FileURLConfig{CDNDomain: "https://cdn.example.com"})
+	got := o.rewrite("http://bucket.s3.amazonaws.com/1/k/a.png?X-Amz-Signature=abc")
+	if got != "https://cdn.example.com/1/k/a.png?X-Amz-Signature=abc" {
+		t.Fatalf("rewrite = %q", got)
+	}
+	if got := o.rewrite("local://1/k/a.png"); got != "local://1/k/a.png" {
+		t.Fatalf("rewrite must leave storage paths alone, got %q", got)
+	}
+	if got := newURLOptions(&types.FileURLConfig{CDNDomain: "cdn.example.com"}).rewrite("https://a/b"); got != "https://a/b" {
+		t.Fatalf("invalid CDN domain must be ignored, got %q", got)
+	}
+}
+
+func TestLocalGetFileURLWithTenantURLConfig(t *testing.T) {
+	t.Setenv("SYSTEM_AES_KEY", "0123456789abcdef0123456789abcdef")
+	t.Setenv(envURLCDNDomain, "")
+	t.Setenv(envURLExpirySeconds, "")
+	svc := NewLocalFileService(t.TempDir(), "https://weknora.example.com")
+	applyURLConfig(svc, &types.FileURLConfig{ExpirySeconds: 300, CDNDomain: "https://cdn.example.com"})
+
+	before := time.Now()
+	link, err := svc.GetFileURL(context.Background(), "local://1/k/a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Host != "cdn.example.com" || !strings.HasSuffix(u.Path, "/api/v1/files/presigned") {
+		t.Fatalf("link = %q, want the presigned path on the CDN host", link)
+	}
+	expires, _ := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	if want := before.Add(5 * time.Minute).Unix(); expires < want || expires > want+2 {
+		t.Fatalf("expires = %d, want about %d", expires, want)
+	}
+
+	link, err = GetFileURLWithExpiry(context.Background(), svc, "local://1/k/a.png", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ = url.Parse(link)
+	expires, _ = strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	if want := before.Add(time.Hour).Unix(); expires < want {
+		t.Fatalf("expires = %d, want the requested hour", expires)
+	}
+}
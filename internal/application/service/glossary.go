@@ -0,0 +1,416 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// glossaryDefaultMaxTerms caps how many mined terms are sent to the LLM.
+	glossaryDefaultMaxTerms = 100
+	// glossaryDefineBatchSize is the number of terms defined per LLM call.
+	glossaryDefineBatchSize = 15
+	// glossaryMaxMinedBytes bounds the amount of chunk text scanned per build.
+	glossaryMaxMinedBytes = 8 << 20
+	// glossaryCacheTTL bounds how stale chat-time glossary lookups may be on
+	// instances that did not run the build themselves.
+	glossaryCacheTTL = 5 * time.Minute
+	// glossaryMaxMatches caps the entries injected into one prompt.
+	glossaryMaxMatches = 10
+)
+
+const glossaryDefinePrompt = `You are building a glossary for a knowledge base.
+For every term below, write a concise definition (one or two sentences) in {{language}}, using only the excerpts given for that term.
+If the excerpts do not make the meaning clear, return an empty definition for that term. Do not invent meanings.
+
+Respond with a JSON array only, no commentary:
+[{"term": "<term exactly as given>", "definition": "<definition>"}]
+
+Terms:
+{{terms}}`
+
+type glossaryCacheEntry struct {
+	entries   []*types.GlossaryEntry
+	expiresAt time.Time
+}
+
+// glossaryService mines domain terms from a knowledge base, has the KB's
+// summary model define them, and stores the result as a glossary knowledge
+// whose chunks are looked up by exact term at chat time.
+type glossaryService struct {
+	kbRepo        interfaces.KnowledgeBaseRepository
+	knowledgeRepo interfaces.KnowledgeRepository
+	chunkRepo     interfaces.ChunkRepository
+	modelService  interfaces.ModelService
+	task          interfaces.TaskEnqueuer
+
+	mu    sync.Mutex
+	cache map[string]glossaryCacheEntry // "<tenant>/<kb>" -> entries
+}
+
+// NewGlossaryService creates the glossary service.
+func NewGlossaryService(
+	kbRepo interfaces.KnowledgeBaseRepository,
+	knowledgeRepo interfaces.KnowledgeRepository,
+	chunkRepo interfaces.ChunkRepository,
+	modelService interfaces.ModelService,
+	task interfaces.TaskEnqueuer,
+) interfaces.GlossaryService {
+	return &glossaryService{
+		kbRepo:        kbRepo,
+		knowledgeRepo: knowledgeRepo,
+		chunkRepo:     chunkRepo,
+		modelService:  modelService,
+		task:          task,
+		cache:         make(map[string]glossaryCacheEntry),
+	}
+}
+
+// BuildGlossary enqueues a glossary build for the knowledge base.
+func (s *glossaryService) BuildGlossary(ctx context.Context, kbID string) error {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil || kb.TenantID != tenantID {
+		return werrors.NewNotFoundError("知识库不存在")
+	}
+	if kb.SummaryModelID == "" {
+		return werrors.NewBadRequestError("知识库未配置摘要模型，无法生成术语表")
+	}
+
+	lang, _ := types.LanguageFromContext(ctx)
+	payload := types.GlossaryBuildPayload{
+		TenantID:        tenantID,
+		KnowledgeBaseID: kbID,
+		Language:        lang,
+	}
+	langfuse.InjectTracing(ctx, &payload)
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	task := asynq.NewTask(types.TypeGlossaryBuild, payloadBytes,
+		asynq.Queue(types.QueueLow), asynq.MaxRetry(2), asynq.Timeout(30*time.Minute))
+	info, err := s.task.Enqueue(task)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to enqueue glossary build task: %v", err)
+		return err
+	}
+	logger.Infof(ctx, "Glossary build task enqueued: %s, knowledge base ID: %s", info.ID, kbID)
+	return nil
+}
+
+// ListGlossary returns the stored glossary entries of the knowledge base.
+func (s *glossaryService) ListGlossary(ctx context.Context, kbID string) ([]*types.GlossaryEntry, error) {
+	return s.loadEntries(ctx, types.MustTenantIDFromContext(ctx), kbID)
+}
+
+// MatchGlossary returns the glossary entries of the targets' knowledge
+// bases whose term occurs in text, most frequent terms first.
+func (s *glossaryService) MatchGlossary(
+	ctx context.Context, targets types.SearchTargets, text string,
+) ([]*types.GlossaryEntry, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	var matched []*types.GlossaryEntry
+	seen := make(map[string]bool)
+	for kbID, tenantID := range targets.GetKBTenantMap() {
+		entries, err := s.cachedEntries(ctx, tenantID, kbID)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if seen[e.Term] || !containsGlossaryTerm(text, e.Term) {
+				continue
+			}
+			seen[e.Term] = true
+			matched = append(matched, e)
+		}
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].Occurrences > matched[j].Occurrences })
+	if len(matched) > glossaryMaxMatches {
+		matched = matched[:glossaryMaxMatches]
+	}
+	return matched, nil
+}
+
+func (s *glossaryService) cachedEntries(ctx context.Context, tenantID uint64, kbID string) ([]*types.GlossaryEntry, error) {
+	key := fmt.Sprintf("%d/%s", tenantID, kbID)
+	s.mu.Lock()
+	cached, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.entries, nil
+	}
+	entries, err := s.loadEntries(ctx, tenantID, kbID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.cache[key] = glossaryCacheEntry{entries: entries, expiresAt: time.Now().Add(glossaryCacheTTL)}
+	s.mu.Unlock()
+	return entries, nil
+}
+
+func (s *glossaryService) invalidate(tenantID uint64, kbID string) {
+	s.mu.Lock()
+	delete(s.cache, fmt.Sprintf("%d/%s", tenantID, kbID))
+	s.mu.Unlock()
+}
+
+func (s *glossaryService) loadEntries(ctx context.Context, tenantID uint64, kbID string) ([]*types.GlossaryEntry, error) {
+	knowledge, err := s.findGlossaryKnowledge(ctx, tenantID, kbID)
+	if err != nil || knowledge == nil {
+		return nil, err
+	}
+	chunks, err := s.chunkRepo.ListChunksByKnowledgeID(ctx, tenantID, knowledge.ID)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*types.GlossaryEntry, 0, len(chunks))
+	for _, c := range chunks {
+		if c.ChunkType != types.ChunkTypeGlossary || !c.IsEnabled {
+			continue
+		}
+		entry, err := c.GlossaryMetadata()
+		if err != nil || entry == nil {
+			logger.Warnf(ctx, "Skip malformed glossary chunk %s: %v", c.ID, err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+func (s *glossaryService) findGlossaryKnowledge(
+	ctx context.Context, tenantID uint64, kbID string,
+) (*types.Knowledge, error) {
+	knowledges, err := s.knowledgeRepo.ListKnowledgeByKnowledgeBaseID(ctx, tenantID, kbID)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range knowledges {
+		if k.Type == types.KnowledgeTypeGlossary {
+			return k, nil
+		}
+	}
+	return nil, nil
+}
+
+// ProcessGlossaryBuild mines terms from the KB's document chunks, defines
+// them with the KB's summary model and replaces the stored glossary.
+func (s *glossaryService) ProcessGlossaryBuild(ctx context.Context, t *asynq.Task) error {
+	var payload types.GlossaryBuildPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "Failed to unmarshal glossary build payload: %v", err)
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	if payload.Language != "" {
+		ctx = context.WithValue(ctx, types.LanguageContextKey, payload.Language)
+	}
+	tenantID, kbID := payload.TenantID, payload.KnowledgeBaseID
+	logger.Infof(ctx, "Processing glossary build for knowledge base: %s", kbID)
+
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	if kb == nil || kb.TenantID != tenantID {
+		logger.Warnf(ctx, "Knowledge base %s not found, skip glossary build", kbID)
+		return nil
+	}
+	chatModel, err := s.modelService.GetChatModel(ctx, kb.SummaryModelID)
+	if err != nil {
+		return fmt.Errorf("get summary model: %w", err)
+	}
+
+	texts, err := s.collectTexts(ctx, tenantID, kbID)
+	if err != nil {
+		return err
+	}
+	maxTerms := payload.MaxTerms
+	if maxTerms <= 0 {
+		maxTerms = glossaryDefaultMaxTerms
+	}
+	candidates := mineGlossaryTerms(texts, maxTerms)
+	logger.Infof(ctx, "Glossary mining scanned %d chunks, %d candidate terms", len(texts), len(candidates))
+
+	var entries []*types.GlossaryEntry
+	for start := 0; start < len(candidates); start += glossaryDefineBatchSize {
+		batch := candidates[start:min(start+glossaryDefineBatchSize, len(candidates))]
+		defined, err := s.defineTerms(ctx, chatModel, batch)
+		if err != nil {
+			// One bad batch should not discard the rest of the glossary.
+			logger.Warnf(ctx, "Glossary definition batch %d failed: %v", start/glossaryDefineBatchSize, err)
+			continue
+		}
+		entries = append(entries, defined...)
+	}
+
+	if err := s.storeGlossary(ctx, kb, entries); err != nil {
+		return err
+	}
+	s.invalidate(tenantID, kbID)
+	logger.Infof(ctx, "Glossary built for knowledge base %s: %d terms", kbID, len(entries))
+	return nil
+}
+
+// collectTexts returns the text chunks of the KB's parsed documents, up to
+// glossaryMaxMinedBytes.
+func (s *glossaryService) collectTexts(ctx context.Context, tenantID uint64, kbID string) ([]string, error) {
+	knowledges, err := s.knowledgeRepo.ListKnowledgeByKnowledgeBaseID(ctx, tenantID, kbID)
+	if err != nil {
+		return nil, err
+	}
+	var texts []string
+	total := 0
+	for _, k := range knowledges {
+		if k.Type == types.KnowledgeTypeGlossary || k.Type == types.KnowledgeTypeFAQ ||
+			k.ParseStatus != types.ParseStatusCompleted {
+			continue
+		}
+		chunks, err := s.chunkRepo.ListChunksByKnowledgeID(ctx, tenantID, k.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range chunks {
+			if c.ChunkType != types.ChunkTypeText || !c.IsEnabled {
+				continue
+			}
+			texts = append(texts, c.Content)
+			total += len(c.Content)
+			if total >= glossaryMaxMinedBytes {
+				return texts, nil
+			}
+		}
+	}
+	return texts, nil
+}
+
+// defineTerms asks the model to define one batch of candidates. Terms the
+// model could not define from the excerpts are dropped.
+func (s *glossaryService) defineTerms(
+	ctx context.Context, chatModel chat.Chat, batch []*glossaryCandidate,
+) ([]*types.GlossaryEntry, error) {
+	var terms strings.Builder
+	for _, c := range batch {
+		terms.WriteString("\n### " + c.Term)
+		if c.Expansion != "" {
+			terms.WriteString(" (written out as: " + c.Expansion + ")")
+		}
+		terms.WriteString("\n")
+		for _, excerpt := range c.Contexts {
+			terms.WriteString("- " + excerpt + "\n")
+		}
+	}
+	prompt := types.RenderPromptPlaceholders(glossaryDefinePrompt, types.PlaceholderValues{
+		"language": types.LanguageNameFromContext(ctx),
+		"terms":    terms.String(),
+	})
+
+	thinking := false
+	resp, err := chatModel.Chat(ctx, []chat.Message{{Role: "user", Content: prompt}}, &chat.ChatOptions{
+		Temperature: 0.1,
+		MaxTokens:   4096,
+		Thinking:    &thinking,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var defined []struct {
+		Term       string `json:"term"`
+		Definition string `json:"definition"`
+	}
+	if err := json.Unmarshal([]byte(cleanLLMJSON(resp.Content)), &defined); err != nil {
+		return nil, fmt.Errorf("parse definitions: %w", err)
+	}
+
+	byTerm := make(map[string]*glossaryCandidate, len(batch))
+	for _, c := range batch {
+		byTerm[c.Term] = c
+	}
+	entries := make([]*types.GlossaryEntry, 0, len(defined))
+	for _, d := range defined {
+		c, ok := byTerm[strings.TrimSpace(d.Term)]
+		definition := strings.TrimSpace(d.Definition)
+		if !ok || definition == "" {
+			continue
+		}
+		delete(byTerm, c.Term)
+		entries = append(entries, &types.GlossaryEntry{
+			Term:        c.Term,
+			Definition:  definition,
+			Expansion:   c.Expansion,
+			Occurrences: c.Occurrences,
+		})
+	}
+	return entries, nil
+}
+
+// storeGlossary replaces the KB's glossary chunks, creating the glossary
+// knowledge on first build. Glossary chunks are not vector indexed; they are
+// served by exact term match.
+func (s *glossaryService) storeGlossary(ctx context.Context, kb *types.KnowledgeBase, entries []*types.GlossaryEntry) error {
+	knowledge, err := s.findGlossaryKnowledge(ctx, kb.TenantID, kb.ID)
+	if err != nil {
+		return err
+	}
+	if knowledge == nil {
+		knowledge = &types.Knowledge{
+			ID:              uuid.New().String(),
+			TenantID:        kb.TenantID,
+			KnowledgeBaseID: kb.ID,
+			Type:            types.KnowledgeTypeGlossary,
+			Channel:         types.ChannelWeb,
+			Title:           "Glossary",
+			Description:     "自动生成的术语表",
+			Source:          types.KnowledgeTypeGlossary,
+			ParseStatus:     types.ParseStatusCompleted,
+			EnableStatus:    "enabled",
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
+		}
+		if err := s.knowledgeRepo.CreateKnowledge(ctx, knowledge); err != nil {
+			return err
+		}
+	} else if err := s.chunkRepo.DeleteChunksByKnowledgeID(ctx, kb.TenantID, knowledge.ID); err != nil {
+		return err
+	}
+
+	chunks := make([]*types.Chunk, 0, len(entries))
+	for i, e := range entries {
+		chunk := &types.Chunk{
+			ID:              uuid.New().String(),
+			TenantID:        kb.TenantID,
+			KnowledgeID:     knowledge.ID,
+			KnowledgeBaseID: kb.ID,
+			ChunkIndex:      i,
+			IsEnabled:       true,
+			ChunkType:       types.ChunkTypeGlossary,
+		}
+		if err := chunk.SetGlossaryMetadata(e); err != nil {
+			return err
+		}
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+	return s.chunkRepo.CreateChunks(ctx, chunks)
+}
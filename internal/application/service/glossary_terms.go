@@ -0,0 +1,177 @@
+package service
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Term mining for the knowledge base glossary. The heuristics are kept
+// deliberately simple and language-agnostic enough for mixed zh/en corpora:
+//
+//   - acronyms: all-caps tokens of 2–10 characters (plural "s" folded);
+//   - explicit definitions: "Long Form (ACR)" / "中文全称（ACR）", whose long
+//     form is kept as the expansion hint for the LLM;
+//   - quoted terms: 「术语」 / “术语”.
+//
+// A candidate must recur across chunks to be kept, which filters out most
+// one-off codes and headings.
+
+const (
+	glossaryMinOccurrences   = 3
+	glossaryMinChunks        = 2
+	glossaryMaxContexts      = 3
+	glossaryContextByteWidth = 240
+)
+
+var (
+	glossaryAcronymRe = regexp.MustCompile(`\b([A-Z][A-Z0-9&]{1,9})s?\b`)
+	// "Service Level Agreement (SLA)"
+	glossaryEnExpansionRe = regexp.MustCompile(
+		`((?:[A-Z][A-Za-z]+[ \-]){1,6}[A-Z][A-Za-z]+)\s*[(（]\s*([A-Z][A-Z0-9&]{1,9})\s*[)）]`)
+	// "服务等级协议（SLA）"
+	glossaryZhExpansionRe = regexp.MustCompile(`(\p{Han}{2,16})\s*[(（]\s*([A-Z][A-Z0-9&]{1,9})\s*[)）]`)
+	glossaryQuotedRe      = regexp.MustCompile(`[「“]([\p{Han}A-Za-z0-9]{2,12})[」”]`)
+)
+
+// glossaryStopTerms are all-caps tokens that are words, not domain terms.
+var glossaryStopTerms = map[string]bool{
+	"AM": true, "AN": true, "AND": true, "ARE": true, "AS": true, "AT": true,
+	"BE": true, "BY": true, "DO": true, "FOR": true, "ID": true, "IF": true,
+	"II": true, "III": true, "IN": true, "IS": true, "IT": true, "IV": true,
+	"ME": true, "MY": true, "NO": true, "NOT": true, "NOTE": true, "OF": true,
+	"OK": true, "ON": true, "OR": true, "PM": true, "SO": true, "THE": true,
+	"THIS": true, "TO": true, "TODO": true, "UP": true, "US": true, "VI": true,
+	"WE": true, "WITH": true, "YES": true, "YOU": true,
+}
+
+// glossaryCandidate is a mined term awaiting an LLM definition.
+type glossaryCandidate struct {
+	Term        string
+	Expansion   string
+	Occurrences int
+	Contexts    []string
+	chunks      int
+}
+
+// mineGlossaryTerms scans chunk texts and returns up to maxTerms candidates
+// ordered by frequency.
+func mineGlossaryTerms(texts []string, maxTerms int) []*glossaryCandidate {
+	byTerm := make(map[string]*glossaryCandidate)
+	get := func(term string) *glossaryCandidate {
+		c, ok := byTerm[term]
+		if !ok {
+			c = &glossaryCandidate{Term: term}
+			byTerm[term] = c
+		}
+		return c
+	}
+
+	for _, text := range texts {
+		seen := make(map[string]bool)
+		record := func(term string, start, end int) {
+			c := get(term)
+			c.Occurrences++
+			if !seen[term] {
+				seen[term] = true
+				c.chunks++
+				if len(c.Contexts) < glossaryMaxContexts {
+					c.Contexts = append(c.Contexts, glossaryContext(text, start, end))
+				}
+			}
+		}
+
+		for _, m := range glossaryAcronymRe.FindAllStringSubmatchIndex(text, -1) {
+			term := text[m[2]:m[3]]
+			if glossaryStopTerms[term] || !hasTwoUpper(term) {
+				continue
+			}
+			record(term, m[0], m[1])
+		}
+		for _, m := range glossaryQuotedRe.FindAllStringSubmatchIndex(text, -1) {
+			record(text[m[2]:m[3]], m[0], m[1])
+		}
+		for _, re := range []*regexp.Regexp{glossaryEnExpansionRe, glossaryZhExpansionRe} {
+			for _, m := range re.FindAllStringSubmatch(text, -1) {
+				expansion, acronym := strings.TrimSpace(m[1]), m[2]
+				if re == glossaryEnExpansionRe {
+					// "The Service Level Agreement (SLA)": start at the word
+					// carrying the acronym's initial.
+					words := strings.Fields(expansion)
+					for len(words) > 1 && words[0][0] != acronym[0] {
+						words = words[1:]
+					}
+					if words[0][0] != acronym[0] {
+						continue
+					}
+					expansion = strings.Join(words, " ")
+				}
+				if c := get(acronym); c.Expansion == "" {
+					c.Expansion = expansion
+				}
+			}
+		}
+	}
+
+	var out []*glossaryCandidate
+	for _, c := range byTerm {
+		frequent := c.Occurrences >= glossaryMinOccurrences && c.chunks >= glossaryMinChunks
+		defined := c.Expansion != "" && c.Occurrences >= 2
+		if frequent || defined {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Occurrences != out[j].Occurrences {
+			return out[i].Occurrences > out[j].Occurrences
+		}
+		return out[i].Term < out[j].Term
+	})
+	if maxTerms > 0 && len(out) > maxTerms {
+		out = out[:maxTerms]
+	}
+	return out
+}
+
+// hasTwoUpper filters out tokens like "A1" that are rarely acronyms.
+func hasTwoUpper(s string) bool {
+	n := 0
+	for _, r := range s {
+		if unicode.IsUpper(r) {
+			n++
+		}
+	}
+	return n >= 2
+}
+
+// glossaryContext returns the text around [start,end) with whitespace
+// collapsed, trimmed to valid UTF-8 at the cut points.
+func glossaryContext(text string, start, end int) string {
+	from := max(0, start-glossaryContextByteWidth)
+	to := min(len(text), end+glossaryContextByteWidth)
+	snippet := strings.ToValidUTF8(text[from:to], "")
+	return strings.Join(strings.Fields(snippet), " ")
+}
+
+// containsGlossaryTerm reports whether term occurs in text as a whole token.
+// Terms with an ASCII letter/digit edge must sit on word boundaries ("SLA"
+// does not match "SLAB"); CJK terms match as plain substrings.
+func containsGlossaryTerm(text, term string) bool {
+	if term == "" {
+		return false
+	}
+	needsBoundary := hasASCIILetterEdge(term)
+	for start := 0; start <= len(text)-len(term); {
+		rel := strings.Index(text[start:], term)
+		if rel < 0 {
+			return false
+		}
+		pos := start + rel
+		if !needsBoundary || hasWordBoundary(text, pos, pos+len(term)) {
+			return true
+		}
+		start = pos + 1
+	}
+	return false
+}
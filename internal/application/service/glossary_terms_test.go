@@ -0,0 +1,57 @@
+package service
+
+import "testing"
+
+func TestMineGlossaryTerms(t *testing.T) {
+	texts := []string{
+		"The Service Level Agreement (SLA) defines uptime. THE SLA is reviewed yearly.",
+		"Breaching the SLA triggers credits. See the RTO section.",
+		"SLA credits are paid monthly. 这里使用「灰度发布」策略。",
+		"灰度发布 requires an RTO below one hour. 我们采用「灰度发布」。",
+		"「灰度发布」完成后复盘。",
+	}
+	got := mineGlossaryTerms(texts, 10)
+
+	byTerm := make(map[string]*glossaryCandidate)
+	for _, c := range got {
+		byTerm[c.Term] = c
+	}
+	sla, ok := byTerm["SLA"]
+	if !ok {
+		t.Fatalf("SLA not mined: %+v", got)
+	}
+	if sla.Occurrences != 4 || sla.Expansion != "Service Level Agreement" {
+		t.Fatalf("SLA: occurrences=%d expansion=%q", sla.Occurrences, sla.Expansion)
+	}
+	if got[0].Term != "SLA" {
+		t.Fatalf("most frequent term first: got %q", got[0].Term)
+	}
+	if _, ok := byTerm["灰度发布"]; !ok {
+		t.Fatalf("quoted term not mined: %+v", got)
+	}
+	if _, ok := byTerm["THE"]; ok {
+		t.Fatal("stop term THE should be skipped")
+	}
+	if _, ok := byTerm["RTO"]; ok {
+		t.Fatal("RTO occurs only twice and should not be kept")
+	}
+}
+
+func TestContainsGlossaryTerm(t *testing.T) {
+	cases := []struct {
+		text, term string
+		want       bool
+	}{
+		{"What is our SLA?", "SLA", true},
+		{"A concrete SLAB was poured", "SLA", false},
+		{"the SLA's scope", "SLA", true},
+		{"如何进行灰度发布？", "灰度发布", true},
+		{"SLA要求是多少", "SLA", true},
+		{"", "SLA", false},
+	}
+	for _, tc := range cases {
+		if got := containsGlossaryTerm(tc.text, tc.term); got != tc.want {
+			t.Errorf("containsGlossaryTerm(%q, %q) = %v, want %v", tc.text, tc.term, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,534 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/searchutil"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// graphCommunityBuildDelay is how long a rebuild triggered by ingestion
+	// waits, so a batch of documents is reported on once.
+	graphCommunityBuildDelay = 5 * time.Minute
+	// graphCommunityMaxReports caps the communities reported per build,
+	// largest first.
+	graphCommunityMaxReports = 50
+	// graphCommunityMaxEntities and graphCommunityMaxRelations bound the part
+	// of a community shown to the model.
+	graphCommunityMaxEntities  = 40
+	graphCommunityMaxRelations = 80
+	// graphCommunityMaxChunkIDs caps the source chunks kept per community.
+	graphCommunityMaxChunkIDs = 20
+	// graphCommunityCacheTTL bounds how stale chat-time lookups may be on
+	// instances that did not run the build themselves.
+	graphCommunityCacheTTL = 5 * time.Minute
+	// graphCommunityRatingWeight is the share of a report's score that comes
+	// from its importance rating rather than from matching the query, so the
+	// main themes win among reports the query touches equally.
+	graphCommunityRatingWeight = 0.3
+)
+
+const graphCommunityReportPrompt = `You are analysing one community of a knowledge graph extracted from a knowledge base.
+Using only the entities and relationships below, write a report in {{language}} that helps a reader understand what this community is about.
+
+Respond with a JSON object only, no commentary:
+{"title": "<short title naming the community's key entities>", "summary": "<one paragraph executive summary>", "findings": ["<key insight>", ...], "rating": <importance of the community to the knowledge base, 0-10>}
+
+Give at most five findings. Do not invent facts that are not supported by the data.
+
+Entities:
+{{entities}}
+
+Relationships:
+{{relations}}`
+
+type graphCommunityEntry struct {
+	chunk     *types.Chunk
+	community *types.GraphCommunity
+	tokens    map[string]struct{}
+}
+
+type graphCommunityCacheEntry struct {
+	entries   []*graphCommunityEntry
+	expiresAt time.Time
+}
+
+// graphCommunityService detects communities in a knowledge base's entity
+// graph, has the KB's summary model write a report on each and stores the
+// reports as a graph community knowledge whose chunks answer global
+// questions at chat time.
+type graphCommunityService struct {
+	kbRepo        interfaces.KnowledgeBaseRepository
+	knowledgeRepo interfaces.KnowledgeRepository
+	chunkRepo     interfaces.ChunkRepository
+	modelService  interfaces.ModelService
+	graphEngine   interfaces.RetrieveGraphRepository
+	task          interfaces.TaskEnqueuer
+
+	mu    sync.Mutex
+	cache map[string]graphCommunityCacheEntry // "<tenant>/<kb>" -> entries
+}
+
+// NewGraphCommunityService creates the graph community service.
+func NewGraphCommunityService(
+	kbRepo interfaces.KnowledgeBaseRepository,
+	knowledgeRepo interfaces.KnowledgeRepository,
+	chunkRepo interfaces.ChunkRepository,
+	modelService interfaces.ModelService,
+	graphEngine interfaces.RetrieveGraphRepository,
+	task interfaces.TaskEnqueuer,
+) interfaces.GraphCommunityService {
+	return &graphCommunityService{
+		kbRepo:        kbRepo,
+		knowledgeRepo: knowledgeRepo,
+		chunkRepo:     chunkRepo,
+		modelService:  modelService,
+		graphEngine:   graphEngine,
+		task:          task,
+		cache:         make(map[string]graphCommunityCacheEntry),
+	}
+}
+
+// BuildCommunities enqueues a community build for the knowledge base.
+func (s *graphCommunityService) BuildCommunities(ctx context.Context, kbID string) error {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil || kb.TenantID != tenantID {
+		return werrors.NewNotFoundError("知识库不存在")
+	}
+	if !kb.IsGraphEnabled() || !types.GraphStoreEnabled() {
+		return werrors.NewBadRequestError("知识库未启用知识图谱，无法生成社区报告")
+	}
+	if kb.SummaryModelID == "" {
+		return werrors.NewBadRequestError("知识库未配置摘要模型，无法生成社区报告")
+	}
+	return enqueueGraphCommunityBuild(ctx, s.task, tenantID, kbID)
+}
+
+// ScheduleGraphCommunityBuild enqueues a delayed community rebuild after the
+// knowledge base graph changed. Changes within one graphCommunityBuildDelay
+// window share a single build.
+func ScheduleGraphCommunityBuild(ctx context.Context, client interfaces.TaskEnqueuer, tenantID uint64, kbID string) {
+	if client == nil || kbID == "" {
+		return
+	}
+	window := time.Now().Truncate(graphCommunityBuildDelay).Unix()
+	err := enqueueGraphCommunityBuild(ctx, client, tenantID, kbID,
+		asynq.ProcessIn(graphCommunityBuildDelay),
+		asynq.TaskID(fmt.Sprintf("graph-community:%s:%d", kbID, window)))
+	if err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		logger.Warnf(ctx, "Failed to schedule graph community build for knowledge base %s: %v", kbID, err)
+	}
+}
+
+func enqueueGraphCommunityBuild(
+	ctx context.Context, client interfaces.TaskEnqueuer, tenantID uint64, kbID string, opts ...asynq.Option,
+) error {
+	lang, _ := types.LanguageFromContext(ctx)
+	payload := types.GraphCommunityBuildPayload{
+		TenantID:        tenantID,
+		KnowledgeBaseID: kbID,
+		Language:        lang,
+	}
+	langfuse.InjectTracing(ctx, &payload)
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	opts = append([]asynq.Option{asynq.Queue(types.QueueLow), asynq.MaxRetry(2), asynq.Timeout(30 * time.Minute)}, opts...)
+	info, err := client.Enqueue(asynq.NewTask(types.TypeGraphCommunityBuild, payloadBytes), opts...)
+	if err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Graph community build task enqueued: %s, knowledge base ID: %s", info.ID, kbID)
+	return nil
+}
+
+// ListCommunities returns the stored communities of the knowledge base,
+// most important first.
+func (s *graphCommunityService) ListCommunities(ctx context.Context, kbID string) ([]*types.GraphCommunity, error) {
+	entries, err := s.loadEntries(ctx, types.MustTenantIDFromContext(ctx), kbID)
+	if err != nil {
+		return nil, err
+	}
+	communities := make([]*types.GraphCommunity, 0, len(entries))
+	for _, e := range entries {
+		communities = append(communities, e.community)
+	}
+	sort.SliceStable(communities, func(i, j int) bool { return communities[i].Rating > communities[j].Rating })
+	return communities, nil
+}
+
+// SearchCommunities scores every community report of the targets' knowledge
+// bases by how many query terms it covers, blended with its importance
+// rating, and returns the topK best as graph matches.
+func (s *graphCommunityService) SearchCommunities(
+	ctx context.Context, targets types.SearchTargets, query string, topK int,
+) ([]*types.SearchResult, error) {
+	if topK <= 0 {
+		return nil, nil
+	}
+	queryTokens := searchutil.TokenizeSimple(query)
+	type scored struct {
+		entry *graphCommunityEntry
+		score float64
+	}
+	var candidates []scored
+	for kbID, tenantID := range targets.GetKBTenantMap() {
+		entries, err := s.cachedEntries(ctx, tenantID, kbID)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if score := scoreGraphCommunity(queryTokens, e.tokens, e.community.Rating); score > 0 {
+				candidates = append(candidates, scored{entry: e, score: score})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].entry.chunk.ID < candidates[j].entry.chunk.ID
+	})
+	if len(candidates) > topK {
+		candidates = candidates[:topK]
+	}
+	results := make([]*types.SearchResult, 0, len(candidates))
+	for _, c := range candidates {
+		chunk, community := c.entry.chunk, c.entry.community
+		results = append(results, &types.SearchResult{
+			ID:             chunk.ID,
+			Content:        chunk.Content,
+			KnowledgeID:    chunk.KnowledgeID,
+			ChunkIndex:     chunk.ChunkIndex,
+			KnowledgeTitle: community.Title,
+			StartAt:        chunk.StartAt,
+			EndAt:          chunk.EndAt,
+			Score:          c.score,
+			MatchType:      types.MatchTypeGraph,
+			SubChunkID:     []string{},
+			Metadata: map[string]string{
+				"retriever_type": string(types.GraphRetrieverType),
+				"rating":         strconv.FormatFloat(community.Rating, 'f', -1, 64),
+			},
+			ChunkType:       types.ChunkTypeCommunityReport,
+			KnowledgeSource: types.KnowledgeTypeGraphCommunity,
+			KnowledgeBaseID: chunk.KnowledgeBaseID,
+		})
+	}
+	return results, nil
+}
+
+// scoreGraphCommunity blends the share of query terms a report covers with
+// its 0-10 importance rating. Reports sharing no term with the query score 0.
+func scoreGraphCommunity(queryTokens, reportTokens map[string]struct{}, rating float64) float64 {
+	matched := 0
+	for token := range queryTokens {
+		if _, ok := reportTokens[token]; ok {
+			matched++
+		}
+	}
+	if matched == 0 {
+		return 0
+	}
+	coverage := float64(matched) / float64(len(queryTokens))
+	rating = min(max(rating, 0), 10)
+	return (1-graphCommunityRatingWeight)*coverage + graphCommunityRatingWeight*rating/10
+}
+
+func (s *graphCommunityService) cachedEntries(ctx context.Context, tenantID uint64, kbID string) ([]*graphCommunityEntry, error) {
+	key := fmt.Sprintf("%d/%s", tenantID, kbID)
+	s.mu.Lock()
+	cached, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.entries, nil
+	}
+	entries, err := s.loadEntries(ctx, tenantID, kbID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.cache[key] = graphCommunityCacheEntry{entries: entries, expiresAt: time.Now().Add(graphCommunityCacheTTL)}
+	s.mu.Unlock()
+	return entries, nil
+}
+
+func (s *graphCommunityService) invalidate(tenantID uint64, kbID string) {
+	s.mu.Lock()
+	delete(s.cache, fmt.Sprintf("%d/%s", tenantID, kbID))
+	s.mu.Unlock()
+}
+
+func (s *graphCommunityService) loadEntries(ctx context.Context, tenantID uint64, kbID string) ([]*graphCommunityEntry, error) {
+	knowledge, err := s.findCommunityKnowledge(ctx, tenantID, kbID)
+	if err != nil || knowledge == nil {
+		return nil, err
+	}
+	chunks, err := s.chunkRepo.ListChunksByKnowledgeID(ctx, tenantID, knowledge.ID)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*graphCommunityEntry, 0, len(chunks))
+	for _, c := range chunks {
+		if c.ChunkType != types.ChunkTypeCommunityReport || !c.IsEnabled {
+			continue
+		}
+		community, err := c.GraphCommunityMetadata()
+		if err != nil || community == nil {
+			logger.Warnf(ctx, "Skip malformed community report chunk %s: %v", c.ID, err)
+			continue
+		}
+		community.KnowledgeBaseID = kbID
+		entries = append(entries, &graphCommunityEntry{
+			chunk:     c,
+			community: community,
+			tokens:    searchutil.TokenizeSimple(c.Content + "\n" + strings.Join(community.Entities, " ")),
+		})
+	}
+	return entries, nil
+}
+
+func (s *graphCommunityService) findCommunityKnowledge(
+	ctx context.Context, tenantID uint64, kbID string,
+) (*types.Knowledge, error) {
+	knowledges, err := s.knowledgeRepo.ListKnowledgeByKnowledgeBaseID(ctx, tenantID, kbID)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range knowledges {
+		if k.Type == types.KnowledgeTypeGraphCommunity {
+			return k, nil
+		}
+	}
+	return nil, nil
+}
+
+// ProcessCommunityBuild loads the KB's entity graph, detects its
+// communities, reports on the largest ones with the KB's summary model and
+// replaces the stored reports.
+func (s *graphCommunityService) ProcessCommunityBuild(ctx context.Context, t *asynq.Task) error {
+	var payload types.GraphCommunityBuildPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "Failed to unmarshal graph community build payload: %v", err)
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	if payload.Language != "" {
+		ctx = context.WithValue(ctx, types.LanguageContextKey, payload.Language)
+	}
+	tenantID, kbID := payload.TenantID, payload.KnowledgeBaseID
+	logger.Infof(ctx, "Processing graph community build for knowledge base: %s", kbID)
+
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	if kb == nil || kb.TenantID != tenantID {
+		logger.Warnf(ctx, "Knowledge base %s not found, skip graph community build", kbID)
+		return nil
+	}
+	if !kb.IsGraphEnabled() || kb.SummaryModelID == "" {
+		logger.Warnf(ctx, "Knowledge base %s has no graph or summary model, skip graph community build", kbID)
+		return nil
+	}
+	chatModel, err := s.modelService.GetChatModel(ctx, kb.SummaryModelID)
+	if err != nil {
+		return fmt.Errorf("get summary model: %w", err)
+	}
+
+	graph, err := s.graphEngine.GetGraph(ctx, types.NameSpace{KnowledgeBase: kbID})
+	if err != nil {
+		return fmt.Errorf("load knowledge graph: %w", err)
+	}
+	detected := detectGraphCommunities(graph)
+	logger.Infof(ctx, "Detected %d graph communities in knowledge base %s", len(detected), kbID)
+	if len(detected) > graphCommunityMaxReports {
+		detected = detected[:graphCommunityMaxReports]
+	}
+
+	var communities []*types.GraphCommunity
+	for i, c := range detected {
+		community, err := s.reportCommunity(ctx, chatModel, c)
+		if err != nil {
+			// One bad report should not discard the rest.
+			logger.Warnf(ctx, "Graph community report %d failed: %v", i, err)
+			continue
+		}
+		communities = append(communities, community)
+	}
+
+	if err := s.storeCommunities(ctx, kb, communities); err != nil {
+		return err
+	}
+	s.invalidate(tenantID, kbID)
+	logger.Infof(ctx, "Graph communities built for knowledge base %s: %d reports", kbID, len(communities))
+	return nil
+}
+
+// reportCommunity asks the model for a report on one community.
+func (s *graphCommunityService) reportCommunity(
+	ctx context.Context, chatModel chat.Chat, c *graphCommunity,
+) (*types.GraphCommunity, error) {
+	// Show the best connected entities when the community is too large.
+	degree := make(map[string]int)
+	for _, rel := range c.Relations {
+		degree[rel.Node1]++
+		degree[rel.Node2]++
+	}
+	nodes := append([]*types.GraphNode(nil), c.Nodes...)
+	sort.SliceStable(nodes, func(i, j int) bool { return degree[nodes[i].Name] > degree[nodes[j].Name] })
+	if len(nodes) > graphCommunityMaxEntities {
+		nodes = nodes[:graphCommunityMaxEntities]
+	}
+	shown := make(map[string]bool, len(nodes))
+	var entities strings.Builder
+	var chunkIDs []string
+	names := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		shown[node.Name] = true
+		names = append(names, node.Name)
+		entities.WriteString("- " + node.Name)
+		if len(node.Attributes) > 0 {
+			entities.WriteString(": " + strings.Join(node.Attributes, "; "))
+		}
+		entities.WriteString("\n")
+		chunkIDs = unionChunkIDs(chunkIDs, node.Chunks)
+	}
+	var relations strings.Builder
+	count := 0
+	for _, rel := range c.Relations {
+		if !shown[rel.Node1] || !shown[rel.Node2] || count >= graphCommunityMaxRelations {
+			continue
+		}
+		count++
+		relations.WriteString(fmt.Sprintf("- %s --[%s]--> %s\n", rel.Node1, rel.Type, rel.Node2))
+	}
+	prompt := types.RenderPromptPlaceholders(graphCommunityReportPrompt, types.PlaceholderValues{
+		"language":  types.LanguageNameFromContext(ctx),
+		"entities":  entities.String(),
+		"relations": relations.String(),
+	})
+
+	thinking := false
+	resp, err := chatModel.Chat(ctx, []chat.Message{{Role: "user", Content: prompt}}, &chat.ChatOptions{
+		Temperature: 0.1,
+		MaxTokens:   2048,
+		Thinking:    &thinking,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var report struct {
+		Title    string   `json:"title"`
+		Summary  string   `json:"summary"`
+		Findings []string `json:"findings"`
+		Rating   float64  `json:"rating"`
+	}
+	if err := json.Unmarshal([]byte(cleanLLMJSON(resp.Content)), &report); err != nil {
+		return nil, fmt.Errorf("parse report: %w", err)
+	}
+	if strings.TrimSpace(report.Title) == "" || strings.TrimSpace(report.Summary) == "" {
+		return nil, errors.New("report has no title or summary")
+	}
+	if len(chunkIDs) > graphCommunityMaxChunkIDs {
+		chunkIDs = chunkIDs[:graphCommunityMaxChunkIDs]
+	}
+	return &types.GraphCommunity{
+		Title:    strings.TrimSpace(report.Title),
+		Summary:  strings.TrimSpace(report.Summary),
+		Findings: report.Findings,
+		Rating:   min(max(report.Rating, 0), 10),
+		Entities: names,
+		ChunkIDs: chunkIDs,
+	}, nil
+}
+
+func unionChunkIDs(ids []string, extra []string) []string {
+	for _, id := range extra {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// storeCommunities replaces the KB's community report chunks, creating the
+// graph community knowledge on first build. Report chunks are not vector
+// indexed; SearchCommunities scores them directly.
+func (s *graphCommunityService) storeCommunities(
+	ctx context.Context, kb *types.KnowledgeBase, communities []*types.GraphCommunity,
+) error {
+	knowledge, err := s.findCommunityKnowledge(ctx, kb.TenantID, kb.ID)
+	if err != nil {
+		return err
+	}
+	if knowledge == nil {
+		knowledge = &types.Knowledge{
+			ID:              uuid.New().String(),
+			TenantID:        kb.TenantID,
+			KnowledgeBaseID: kb.ID,
+			Type:            types.KnowledgeTypeGraphCommunity,
+			Channel:         types.ChannelWeb,
+			Title:           "Graph Communities",
+			Description:     "自动生成的知识图谱社区报告",
+			Source:          types.KnowledgeTypeGraphCommunity,
+			ParseStatus:     types.ParseStatusCompleted,
+			EnableStatus:    "enabled",
+			CreatedAt:       time.Now(),
+			UpdatedAt:       time.Now(),
+		}
+		if err := s.knowledgeRepo.CreateKnowledge(ctx, knowledge); err != nil {
+			return err
+		}
+	} else if err := s.chunkRepo.DeleteChunksByKnowledgeID(ctx, kb.TenantID, knowledge.ID); err != nil {
+		return err
+	}
+
+	// Reports are laid out end to end, one rune apart, so the merge stage
+	// never takes two of them for overlapping passages of one document.
+	chunks := make([]*types.Chunk, 0, len(communities))
+	offset := 0
+	for i, c := range communities {
+		chunk := &types.Chunk{
+			ID:              uuid.New().String(),
+			TenantID:        kb.TenantID,
+			KnowledgeID:     knowledge.ID,
+			KnowledgeBaseID: kb.ID,
+			ChunkIndex:      i,
+			IsEnabled:       true,
+			ChunkType:       types.ChunkTypeCommunityReport,
+		}
+		if err := chunk.SetGraphCommunityMetadata(c); err != nil {
+			return err
+		}
+		chunk.StartAt = offset
+		chunk.EndAt = offset + utf8.RuneCountInString(chunk.Content)
+		offset = chunk.EndAt + 1
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) == 0 {
+		return nil
+	}
+	return s.chunkRepo.CreateChunks(ctx, chunks)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// graphCommunityMaxPasses bounds the local-moving passes of community
+// detection; partitions of extracted graphs settle in a handful.
+const graphCommunityMaxPasses = 20
+
+// graphCommunity is one detected community: its entities and the relations
+// between them.
+type graphCommunity struct {
+	Nodes     []*types.GraphNode
+	Relations []*types.GraphRelation
+}
+
+// detectGraphCommunities partitions the graph with the local-moving phase of
+// the Louvain method: every entity in turn joins the neighbouring community
+// that most increases modularity, until no entity moves. Relations count as
+// undirected edges weighted by how often the pair is related. Entities are
+// visited in name order so the same graph always yields the same
+// communities. Isolated entities form no community. Communities are
+// returned largest first.
+func detectGraphCommunities(graph *types.GraphData) []*graphCommunity {
+	if graph == nil {
+		return nil
+	}
+	nodeByName := make(map[string]*types.GraphNode, len(graph.Node))
+	for _, node := range graph.Node {
+		nodeByName[node.Name] = node
+	}
+	for _, rel := range graph.Relation {
+		for _, name := range []string{rel.Node1, rel.Node2} {
+			if _, ok := nodeByName[name]; !ok {
+				nodeByName[name] = &types.GraphNode{Name: name}
+			}
+		}
+	}
+	names := make([]string, 0, len(nodeByName))
+	for name := range nodeByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+	}
+
+	adjacency := make([]map[int]float64, len(names))
+	for i := range adjacency {
+		adjacency[i] = make(map[int]float64)
+	}
+	degree := make([]float64, len(names))
+	totalWeight := 0.0
+	for _, rel := range graph.Relation {
+		a, b := index[rel.Node1], index[rel.Node2]
+		if a == b {
+			continue
+		}
+		adjacency[a][b]++
+		adjacency[b][a]++
+		degree[a]++
+		degree[b]++
+		totalWeight += 2
+	}
+	if totalWeight == 0 {
+		return nil
+	}
+
+	community := make([]int, len(names))
+	communityDegree := make([]float64, len(names))
+	for i := range names {
+		community[i] = i
+		communityDegree[i] = degree[i]
+	}
+	for pass := 0; pass < graphCommunityMaxPasses; pass++ {
+		moved := false
+		for i := range names {
+			if degree[i] == 0 {
+				continue
+			}
+			links := make(map[int]float64)
+			for j, w := range adjacency[i] {
+				links[community[j]] += w
+			}
+			current := community[i]
+			communityDegree[current] -= degree[i]
+			candidates := make([]int, 0, len(links))
+			for c := range links {
+				candidates = append(candidates, c)
+			}
+			sort.Ints(candidates)
+			// Gain of joining c, up to a constant factor:
+			// k_i,in(c) - tot(c) * k_i / 2m.
+			best := current
+			bestGain := links[current] - communityDegree[current]*degree[i]/totalWeight
+			for _, c := range candidates {
+				gain := links[c] - communityDegree[c]*degree[i]/totalWeight
+				if gain > bestGain+1e-12 {
+					best, bestGain = c, gain
+				}
+			}
+			communityDegree[best] += degree[i]
+			if best != current {
+				community[i] = best
+				moved = true
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range names {
+		if degree[i] > 0 {
+			members[community[i]] = append(members[community[i]], i)
+		}
+	}
+	var communities []*graphCommunity
+	byLabel := make(map[int]*graphCommunity)
+	for label, nodes := range members {
+		if len(nodes) < 2 {
+			continue
+		}
+		c := &graphCommunity{}
+		for _, i := range nodes {
+			c.Nodes = append(c.Nodes, nodeByName[names[i]])
+		}
+		byLabel[label] = c
+		communities = append(communities, c)
+	}
+	for _, rel := range graph.Relation {
+		a, b := index[rel.Node1], index[rel.Node2]
+		if c, ok := byLabel[community[a]]; ok && community[a] == community[b] && a != b {
+			c.Relations = append(c.Relations, rel)
+		}
+	}
+	sort.SliceStable(communities, func(i, j int) bool {
+		if len(communities[i].Nodes) != len(communities[j].Nodes) {
+			return len(communities[i].Nodes) > len(communities[j].Nodes)
+		}
+		return communities[i].Nodes[0].Name < communities[j].Nodes[0].Name
+	})
+	return communities
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func communityGraph(relations ...[2]string) *types.GraphData {
+	graph := &types.GraphData{}
+	for _, rel := range relations {
+		graph.Relation = append(graph.Relation, &types.GraphRelation{Node1: rel[0], Node2: rel[1], Type: "RELATED"})
+	}
+	return graph
+}
+
+func communityNames(c *graphCommunity) []string {
+	names := make([]string, 0, len(c.Nodes))
+	for _, node := range c.Nodes {
+		names = append(names, node.Name)
+	}
+	return names
+}
+
+func TestDetectGraphCommunitiesSplitsClusters(t *testing.T) {
+	// Two triangles joined by a single bridge, plus an isolated entity.
+	graph := communityGraph(
+		[2]string{"A", "B"}, [2]string{"B", "C"}, [2]string{"C", "A"},
+		[2]string{"D", "E"}, [2]string{"E", "F"}, [2]string{"F", "D"}, [2]string{"F", "G"}, [2]string{"G", "D"},
+		[2]string{"C", "D"},
+	)
+	graph.Node = append(graph.Node, &types.GraphNode{Name: "Lonely", Chunks: []string{"c1"}})
+
+	communities := detectGraphCommunities(graph)
+	require.Len(t, communities, 2)
+	assert.Equal(t, []string{"D", "E", "F", "G"}, communityNames(communities[0]))
+	assert.Equal(t, []string{"A", "B", "C"}, communityNames(communities[1]))
+	assert.Len(t, communities[0].Relations, 5)
+	assert.Len(t, communities[1].Relations, 3)
+}
+
+func TestDetectGraphCommunitiesIsDeterministic(t *testing.T) {
+	graph := communityGraph(
+		[2]string{"A", "B"}, [2]string{"B", "C"}, [2]string{"C", "A"},
+		[2]string{"X", "Y"}, [2]string{"Y", "Z"}, [2]string{"Z", "X"},
+		[2]string{"C", "X"},
+	)
+	first := detectGraphCommunities(graph)
+	for i := 0; i < 5; i++ {
+		again := detectGraphCommunities(graph)
+		require.Len(t, again, len(first))
+		for j := range first {
+			assert.Equal(t, communityNames(first[j]), communityNames(again[j]))
+		}
+	}
+}
+
+func TestDetectGraphCommunitiesEmpty(t *testing.T) {
+	assert.Nil(t, detectGraphCommunities(nil))
+	assert.Nil(t, detectGraphCommunities(&types.GraphData{
+		Node: []*types.GraphNode{{Name: "A"}, {Name: "B"}},
+	}))
+	assert.Nil(t, detectGraphCommunities(communityGraph([2]string{"A", "A"})))
+}
+
+func TestScoreGraphCommunity(t *testing.T) {
+	query := map[string]struct{}{"alice": {}, "acme": {}}
+	report := map[string]struct{}{"alice": {}, "berlin": {}}
+	assert.InDelta(t, 0.7*0.5+0.3*0.8, scoreGraphCommunity(query, report, 8), 1e-9)
+	assert.InDelta(t, 0.7*0.5, scoreGraphCommunity(query, report, -3), 1e-9)
+	// Reports sharing no term with the query are not retrieved, however important.
+	assert.Zero(t, scoreGraphCommunity(query, map[string]struct{}{"berlin": {}}, 10))
+	assert.Zero(t, scoreGraphCommunity(map[string]struct{}{}, report, 10))
+}
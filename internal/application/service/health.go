@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v6/neo4j"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/errgroup"
+	"gorm.io/gorm"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/utils/ollama"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+const (
+	// healthCheckTimeout bounds each dependency probe, so one hanging
+	// dependency does not hold up the whole report
+	healthCheckTimeout = 5 * time.Second
+	// healthCheckConcurrency bounds the probes running at once
+	healthCheckConcurrency = 8
+)
+
+// healthProbeClient probes model endpoints. Like LLM calls it validates the
+// resolved address against SSRF, and it does not follow redirects: any
+// answer shows the endpoint is reachable.
+var healthProbeClient = &http.Client{
+	Timeout: healthCheckTimeout,
+	Transport: &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         secutils.SSRFSafeDialContext,
+		TLSHandshakeTimeout: healthCheckTimeout,
+	},
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// healthService probes the database, Redis, Milvus, Neo4j, object storage
+// and the model endpoints of the current tenant. Only the database and
+// Redis are critical: every request needs them, while without the others
+// the server keeps answering with fewer features, and they are shared by
+// all instances, so taking one instance out of a load balancer would not help.
+type healthService struct {
+	db             *gorm.DB
+	redisClient    *redis.Client
+	neo4jDriver    neo4j.Driver
+	fileService    interfaces.FileService
+	engineRegistry interfaces.RetrieveEngineRegistry
+	modelService   interfaces.ModelService
+	ollamaService  *ollama.OllamaService
+	now            func() time.Time
+}
+
+// NewHealthService creates the health service. redisClient and neo4jDriver
+// are nil when those dependencies are not configured.
+func NewHealthService(
+	db *gorm.DB,
+	redisClient *redis.Client,
+	neo4jDriver neo4j.Driver,
+	fileService interfaces.FileService,
+	engineRegistry interfaces.RetrieveEngineRegistry,
+	modelService interfaces.ModelService,
+	ollamaService *ollama.OllamaService,
+) interfaces.HealthService {
+	return &healthService{
+		db:             db,
+		redisClient:    redisClient,
+		neo4jDriver:    neo4jDriver,
+		fileService:    fileService,
+		engineRegistry: engineRegistry,
+		modelService:   modelService,
+		ollamaService:  ollamaService,
+		now:            time.Now,
+	}
+}
+
+// healthCheck probes one dependency
+type healthCheck func(ctx context.Context) *types.DependencyHealth
+
+// CheckHealth probes every dependency in parallel and aggregates the results
+func (s *healthService) CheckHealth(ctx context.Context) *types.SystemHealth {
+	checks := []healthCheck{s.checkDatabase, s.checkRedis, s.checkMilvus, s.checkNeo4j, s.checkObjectStorage}
+	checks = append(checks, s.modelChecks(ctx)...)
+
+	results := make([]*types.DependencyHealth, len(checks))
+	var g errgroup.Group
+	g.SetLimit(healthCheckConcurrency)
+	for i, check := range checks {
+		g.Go(func() error {
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+			start := time.Now()
+			result := check(checkCtx)
+			if result.Status != types.HealthStatusDisabled {
+				result.LatencyMs = time.Since(start).Milliseconds()
+			}
+			if result.Status == types.HealthStatusDown {
+				logger.Warnf(ctx, "Health check: %s is down: %s", result.Name, result.Message)
+			}
+			results[i] = result
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return types.NewSystemHealth(results, s.now())
+}
+
+// probeResult sets the status of result from the error of a probe
+func probeResult(result *types.DependencyHealth, err error) *types.DependencyHealth {
+	if err != nil {
+		result.Status = types.HealthStatusDown
+		result.Message = err.Error()
+	} else {
+		result.Status = types.HealthStatusOK
+	}
+	return result
+}
+
+func (s *healthService) checkDatabase(ctx context.Context) *types.DependencyHealth {
+	result := &types.DependencyHealth{
+		Name: s.db.Dialector.Name(), Kind: types.DependencyDatabase, Critical: true,
+	}
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return probeResult(result, err)
+	}
+	return probeResult(result, sqlDB.PingContext(ctx))
+}
+
+func (s *healthService) checkRedis(ctx context.Context) *types.DependencyHealth {
+	result := &types.DependencyHealth{Name: "redis", Kind: types.DependencyCache, Critical: true}
+	if s.redisClient == nil {
+		result.Status, result.Message = types.HealthStatusDisabled, "REDIS_ADDR is not set"
+		return result
+	}
+	return probeResult(result, s.redisClient.Ping(ctx).Err())
+}
+
+// checkMilvus reports the load state of the collections of the Milvus
+// engine configured by RETRIEVE_DRIVER. Collections that are not loaded
+// yet make Milvus degraded: searches on them wait for the load.
+func (s *healthService) checkMilvus(ctx context.Context) *types.DependencyHealth {
+	result := &types.DependencyHealth{Name: "milvus", Kind: types.DependencyVectorStore}
+	engine, err := s.engineRegistry.GetRetrieveEngineService(types.MilvusRetrieverEngineType)
+	if err != nil {
+		result.Status, result.Message = types.HealthStatusDisabled, "RETRIEVE_DRIVER does not include milvus"
+		return result
+	}
+	reporter, ok := engine.(interfaces.LoadStateReporter)
+	if !ok {
+		result.Status, result.Message = types.HealthStatusDisabled, "engine does not report collection load state"
+		return result
+	}
+	report, err := reporter.LoadStates(ctx)
+	if err != nil {
+		return probeResult(result, err)
+	}
+	result.Status, result.Collections = types.HealthStatusOK, report.Collections
+	if !report.Ready {
+		notLoaded := 0
+		for _, collection := range report.Collections {
+			if collection.State != types.CollectionLoaded {
+				notLoaded++
+			}
+		}
+		result.Status = types.HealthStatusDegraded
+		result.Message = fmt.Sprintf("%d of %d collections are not loaded", notLoaded, len(report.Collections))
+	}
+	return result
+}
+
+func (s *healthService) checkNeo4j(ctx context.Context) *types.DependencyHealth {
+	result := &types.DependencyHealth{Name: "neo4j", Kind: types.DependencyGraphDatabase}
+	if s.neo4jDriver == nil {
+		result.Status, result.Message = types.HealthStatusDisabled, "NEO4J_ENABLE is not true"
+		return result
+	}
+	return probeResult(result, s.neo4jDriver.VerifyConnectivity(ctx))
+}
+
+func (s *healthService) checkObjectStorage(ctx context.Context) *types.DependencyHealth {
+	storageType := strings.TrimSpace(os.Getenv("STORAGE_TYPE"))
+	if storageType == "" {
+		storageType = "local"
+	}
+	result := &types.DependencyHealth{Name: storageType, Kind: types.DependencyObjectStorage}
+	return probeResult(result, s.fileService.CheckConnectivity(ctx))
+}
+
+// endpointProbe is one probe of a model endpoint, shared by the models
+// served from it
+type endpointProbe struct {
+	once   sync.Once
+	status types.HealthStatus
+	err    error
+}
+
+// modelChecks returns a check for each model of the current tenant. Models
+// on the same endpoint share one probe.
+func (s *healthService) modelChecks(ctx context.Context) []healthCheck {
+	models, err := s.modelService.ListModels(ctx)
+	if err != nil {
+		return []healthCheck{func(context.Context) *types.DependencyHealth {
+			return probeResult(&types.DependencyHealth{Name: "models", Kind: types.DependencyModel}, err)
+		}}
+	}
+
+	probes := make(map[string]*endpointProbe)
+	checks := make([]healthCheck, 0, len(models))
+	for _, model := range models {
+		key := model.Parameters.BaseURL
+		if model.Source == types.ModelSourceLocal {
+			key = string(types.ModelSourceLocal)
+		}
+		if probes[key] == nil {
+			probes[key] = &endpointProbe{}
+		}
+		probe := probes[key]
+		checks = append(checks, func(ctx context.Context) *types.DependencyHealth {
+			return s.checkModel(ctx, model, probe)
+		})
+	}
+	return checks
+}
+
+// checkModel probes the endpoint of a model: Ollama for local models and
+// the base URL for the others
+func (s *healthService) checkModel(
+	ctx context.Context, model *types.Model, probe *endpointProbe,
+) *types.DependencyHealth {
+	name := model.DisplayName
+	if name == "" {
+		name = model.Name
+	}
+	result := &types.DependencyHealth{
+		Name: name,
+		Kind: types.DependencyModel,
+		Model: &types.ModelEndpointHealth{
+			ID: model.ID, Type: model.Type, Source: model.Source,
+		},
+	}
+	if !model.IsBuiltin {
+		result.Model.Endpoint = displayEndpoint(model.Parameters.BaseURL)
+	}
+	if model.Source != types.ModelSourceLocal && model.Parameters.BaseURL == "" {
+		result.Status, result.Message = types.HealthStatusDisabled, "no base URL configured"
+		return result
+	}
+
+	probe.once.Do(func() {
+		if model.Source == types.ModelSourceLocal {
+			probe.status, probe.err = s.probeOllama(ctx)
+		} else {
+			probe.status, probe.err = probeModelEndpoint(ctx, model.Parameters.BaseURL)
+		}
+	})
+	result.Status = probe.status
+	if probe.err != nil {
+		result.Message = probe.err.Error()
+		if model.IsBuiltin {
+			result.Message = "endpoint is unreachable"
+		}
+	}
+	return result
+}
+
+func (s *healthService) probeOllama(ctx context.Context) (types.HealthStatus, error) {
+	if s.ollamaService == nil {
+		return types.HealthStatusDown, fmt.Errorf("ollama service is not initialized")
+	}
+	version, err := s.ollamaService.GetVersion(ctx)
+	if err != nil {
+		return types.HealthStatusDown, err
+	}
+	// GetVersion reports an optional Ollama that failed to start as unavailable
+	if version == "unavailable" {
+		return types.HealthStatusDown, fmt.Errorf("ollama is unavailable")
+	}
+	return types.HealthStatusOK, nil
+}
+
+// probeModelEndpoint sends a GET to the base URL of a model. Any HTTP
+// answer shows the endpoint is reachable; most APIs answer 401 or 404 on
+// their base URL. Server errors mean it is up but failing.
+func probeModelEndpoint(ctx context.Context, baseURL string) (types.HealthStatus, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL, nil)
+	if err != nil {
+		return types.HealthStatusDown, err
+	}
+	resp, err := healthProbeClient.Do(req)
+	if err != nil {
+		// Drop the URL from the error, its query may hold an API key
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return types.HealthStatusDown, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return types.HealthStatusDegraded, fmt.Errorf("endpoint answered HTTP %d", resp.StatusCode)
+	}
+	return types.HealthStatusOK, nil
+}
+
+// displayEndpoint strips credentials and query parameters from a base URL
+func displayEndpoint(baseURL string) string {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host + u.Path
+}
@@ -0,0 +1,626 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/infrastructure/chunker"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// indexMigrationChunkTypes are the chunk types an index migration rebuilds;
+// the same set CloneChunk treats as a document's own content. Other chunk
+// types (tables, wiki pages, ...) are left in place and stay visible.
+var indexMigrationChunkTypes = []types.ChunkType{
+	types.ChunkTypeText, types.ChunkTypeParentText, types.ChunkTypeSummary,
+	types.ChunkTypeImageCaption, types.ChunkTypeImageOCR,
+}
+
+const indexMigrationBatchSize = 100
+
+// errIndexMigrationSuperseded stops a migration worker whose generation was
+// aborted (or replaced) while it was running.
+var errIndexMigrationSuperseded = errors.New("index migration no longer pending")
+
+// indexMigrationService rebuilds the chunks and index rows of a knowledge
+// base as a new index generation next to the live one. Retrieval ignores
+// the new generation until every document is rebuilt, then a single
+// transaction switches the KB over and retires the old chunks, so queries
+// never see a document twice or half-migrated.
+type indexMigrationService struct {
+	kbRepo         interfaces.KnowledgeBaseRepository
+	knowledgeRepo  interfaces.KnowledgeRepository
+	chunkRepo      interfaces.ChunkRepository
+	tenantRepo     interfaces.TenantRepository
+	modelService   interfaces.ModelService
+	retrieveEngine interfaces.RetrieveEngineRegistry
+	ownership      retriever.TenantStoreOwnership
+	task           interfaces.TaskEnqueuer
+}
+
+// NewIndexMigrationService creates the index migration service.
+func NewIndexMigrationService(
+	kbRepo interfaces.KnowledgeBaseRepository,
+	knowledgeRepo interfaces.KnowledgeRepository,
+	chunkRepo interfaces.ChunkRepository,
+	tenantRepo interfaces.TenantRepository,
+	modelService interfaces.ModelService,
+	retrieveEngine interfaces.RetrieveEngineRegistry,
+	ownership retriever.TenantStoreOwnership,
+	task interfaces.TaskEnqueuer,
+) interfaces.IndexMigrationService {
+	return &indexMigrationService{
+		kbRepo:         kbRepo,
+		knowledgeRepo:  knowledgeRepo,
+		chunkRepo:      chunkRepo,
+		tenantRepo:     tenantRepo,
+		modelService:   modelService,
+		retrieveEngine: retrieveEngine,
+		ownership:      ownership,
+		task:           task,
+	}
+}
+
+// StartIndexMigration reserves the next index generation of the knowledge
+// base and enqueues the task that builds it.
+func (s *indexMigrationService) StartIndexMigration(
+	ctx context.Context, kbID string, req *types.IndexMigrationRequest,
+) (int64, error) {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil || kb.TenantID != tenantID {
+		return 0, werrors.NewNotFoundError("知识库不存在")
+	}
+	if kb.Type == types.KnowledgeBaseTypeFAQ {
+		return 0, werrors.NewBadRequestError("FAQ 知识库不支持索引迁移")
+	}
+	if !kb.NeedsEmbeddingModel() {
+		return 0, werrors.NewBadRequestError("知识库未启用向量或关键词索引，无需迁移")
+	}
+	if req.EmbeddingModelID != "" && req.EmbeddingModelID != kb.EmbeddingModelID {
+		if _, err := s.modelService.GetEmbeddingModel(ctx, req.EmbeddingModelID); err != nil {
+			return 0, werrors.NewBadRequestError("嵌入模型不可用: " + err.Error())
+		}
+	}
+
+	generation, err := s.kbRepo.BeginIndexGeneration(ctx, kbID)
+	if err != nil {
+		if errors.Is(err, repository.ErrIndexMigrationInProgress) {
+			return 0, werrors.NewConflictError("知识库已有进行中的索引迁移")
+		}
+		return 0, err
+	}
+
+	lang, _ := types.LanguageFromContext(ctx)
+	payload := types.IndexMigrationPayload{
+		TenantID:         tenantID,
+		KnowledgeBaseID:  kbID,
+		Generation:       generation,
+		Rechunk:          req.Rechunk,
+		EmbeddingModelID: req.EmbeddingModelID,
+		Language:         lang,
+	}
+	langfuse.InjectTracing(ctx, &payload)
+	payloadBytes, err := json.Marshal(payload)
+	if err == nil {
+		task := asynq.NewTask(types.TypeIndexMigration, payloadBytes,
+			asynq.Queue(types.QueueLow), asynq.MaxRetry(0), asynq.Timeout(6*time.Hour))
+		var info *asynq.TaskInfo
+		if info, err = s.task.Enqueue(task); err == nil {
+			logger.Infof(ctx, "Index migration task enqueued: %s, knowledge base ID: %s, generation: %d",
+				info.ID, kbID, generation)
+			return generation, nil
+		}
+	}
+	logger.Errorf(ctx, "Failed to enqueue index migration task: %v", err)
+	if abortErr := s.kbRepo.AbortIndexGeneration(ctx, kbID, generation); abortErr != nil {
+		logger.Warnf(ctx, "Failed to release index generation %d of %s: %v", generation, kbID, abortErr)
+	}
+	return 0, err
+}
+
+// AbortIndexMigration releases the pending generation. The running worker
+// notices before its next document and removes what it already wrote.
+func (s *indexMigrationService) AbortIndexMigration(ctx context.Context, kbID string) error {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil || kb.TenantID != tenantID {
+		return werrors.NewNotFoundError("知识库不存在")
+	}
+	if kb.PendingIndexGeneration == 0 {
+		return werrors.NewBadRequestError("知识库没有进行中的索引迁移")
+	}
+	if err := s.kbRepo.AbortIndexGeneration(ctx, kbID, kb.PendingIndexGeneration); err != nil {
+		if errors.Is(err, repository.ErrIndexGenerationMismatch) {
+			// Finished or aborted concurrently; nothing left to abort.
+			return werrors.NewConflictError("索引迁移已结束")
+		}
+		return err
+	}
+	logger.Infof(ctx, "Index migration aborted, knowledge base ID: %s, generation: %d", kbID, kb.PendingIndexGeneration)
+	return nil
+}
+
+// ProcessIndexMigration handles the index migration task.
+func (s *indexMigrationService) ProcessIndexMigration(ctx context.Context, t *asynq.Task) error {
+	var payload types.IndexMigrationPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "Failed to unmarshal index migration payload: %v", err)
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	if payload.Language != "" {
+		ctx = context.WithValue(ctx, types.LanguageContextKey, payload.Language)
+	}
+	tenantID, kbID, generation := payload.TenantID, payload.KnowledgeBaseID, payload.Generation
+	logger.Infof(ctx, "Processing index migration for knowledge base: %s, generation: %d", kbID, generation)
+
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil || kb.TenantID != tenantID {
+		logger.Warnf(ctx, "Knowledge base %s not found, skip index migration", kbID)
+		return nil
+	}
+	if kb.PendingIndexGeneration != generation {
+		logger.Warnf(ctx, "Index generation %d of %s is no longer pending, skip migration", generation, kbID)
+		return nil
+	}
+
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return s.failMigration(ctx, kb, generation, nil, nil, fmt.Errorf("get tenant: %w", err))
+	}
+	// The factory's unbound path reads TenantInfo from ctx; make sure it's there.
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+	engine, err := retriever.CreateRetrieveEngineForKB(ctx, s.retrieveEngine, s.ownership, tenantID, kb.VectorStoreID)
+	if err != nil {
+		return s.failMigration(ctx, kb, generation, nil, nil, fmt.Errorf("init retrieve engine: %w", err))
+	}
+	targetModelID := payload.EmbeddingModelID
+	if targetModelID == "" {
+		targetModelID = kb.EmbeddingModelID
+	}
+	embedder, err := s.modelService.GetEmbeddingModel(ctx, targetModelID)
+	if err != nil {
+		return s.failMigration(ctx, kb, generation, engine, nil, fmt.Errorf("get embedding model: %w", err))
+	}
+
+	rebuilt, err := s.buildGeneration(ctx, kb, generation, payload.Rechunk, engine, embedder)
+	if err != nil {
+		if errors.Is(err, errIndexMigrationSuperseded) {
+			logger.Infof(ctx, "Index migration of %s was aborted, removing generation %d", kbID, generation)
+			s.discardGeneration(ctx, kb, generation, engine, embedder)
+			return nil
+		}
+		return s.failMigration(ctx, kb, generation, engine, embedder, err)
+	}
+
+	newModelID := ""
+	if targetModelID != kb.EmbeddingModelID {
+		newModelID = targetModelID
+	}
+	retired, err := s.kbRepo.SwitchIndexGeneration(ctx, kbID, generation, indexMigrationChunkTypes, newModelID)
+	if err != nil {
+		if errors.Is(err, repository.ErrIndexGenerationMismatch) {
+			logger.Infof(ctx, "Index migration of %s was aborted before the switch", kbID)
+			s.discardGeneration(ctx, kb, generation, engine, embedder)
+			return nil
+		}
+		return s.failMigration(ctx, kb, generation, engine, embedder, fmt.Errorf("switch generation: %w", err))
+	}
+	logger.Infof(ctx, "Knowledge base %s switched to index generation %d: %d documents rebuilt, %d chunks retired",
+		kbID, generation, rebuilt, len(retired))
+
+	// The retired chunks are already gone from the database, so their index
+	// rows can no longer surface; dropping them only reclaims space.
+	oldEmbedder := embedder
+	if newModelID != "" {
+		if oldEmbedder, err = s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID); err != nil {
+			logger.Warnf(ctx, "Old embedding model of %s unavailable, keeping retired index rows: %v", kbID, err)
+			return nil
+		}
+	}
+	for batch := range slices.Chunk(retired, indexMigrationBatchSize) {
+		if err := engine.DeleteByChunkIDList(ctx, batch, oldEmbedder.GetDimensions(), kb.Type); err != nil {
+			logger.Warnf(ctx, "Failed to delete retired index rows of %s: %v", kbID, err)
+		}
+	}
+	return nil
+}
+
+// buildGeneration writes the new generation of every parsed document and
+// indexes it. It checks before each document that the generation is still
+// pending so an abort takes effect without waiting for the whole KB.
+func (s *indexMigrationService) buildGeneration(
+	ctx context.Context, kb *types.KnowledgeBase, generation int64, rechunk bool,
+	engine *retriever.CompositeRetrieveEngine, embedder embedding.Embedder,
+) (int, error) {
+	knowledgeList, err := s.knowledgeRepo.ListKnowledgeByKnowledgeBaseID(ctx, kb.TenantID, kb.ID)
+	if err != nil {
+		return 0, fmt.Errorf("list knowledge: %w", err)
+	}
+	rebuilt := 0
+	for _, knowledge := range knowledgeList {
+		if knowledge.ParseStatus != types.ParseStatusCompleted {
+			continue
+		}
+		current, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kb.ID)
+		if err != nil {
+			return rebuilt, err
+		}
+		if current.PendingIndexGeneration != generation {
+			return rebuilt, errIndexMigrationSuperseded
+		}
+
+		source, err := s.listMigratedChunks(ctx, knowledge)
+		if err != nil {
+			return rebuilt, fmt.Errorf("list chunks of %s: %w", knowledge.ID, err)
+		}
+		if len(source) == 0 {
+			continue
+		}
+		var chunks []*types.Chunk
+		if rechunk {
+			processOverrides, _ := knowledge.ProcessOverrides()
+			chunks = rechunkToGeneration(source, ResolveProcessConfig(kb, processOverrides).ChunkingConfig, generation)
+		} else {
+			chunks = copyToGeneration(source, generation)
+		}
+
+		for batch := range slices.Chunk(chunks, indexMigrationBatchSize) {
+			if err := s.chunkRepo.CreateChunks(ctx, batch); err != nil {
+				return rebuilt, fmt.Errorf("create chunks of %s: %w", knowledge.ID, err)
+			}
+		}
+		if infos := generationIndexInfo(knowledge, chunks); len(infos) > 0 {
+			if err := engine.BatchIndex(ctx, embedder, infos); err != nil {
+				return rebuilt, fmt.Errorf("index %s: %w", knowledge.ID, err)
+			}
+		}
+		rebuilt++
+	}
+	return rebuilt, nil
+}
+
+// listMigratedChunks returns the live chunks of knowledge that a migration
+// rebuilds, in chunk_index order.
+func (s *indexMigrationService) listMigratedChunks(
+	ctx context.Context, knowledge *types.Knowledge,
+) ([]*types.Chunk, error) {
+	var all []*types.Chunk
+	for page := 1; ; page++ {
+		chunks, _, err := s.chunkRepo.ListPagedChunksByKnowledgeID(ctx, knowledge.TenantID, knowledge.ID,
+			&types.Pagination{Page: page, PageSize: indexMigrationBatchSize},
+			indexMigrationChunkTypes, "", "", "", "", "")
+		if err != nil {
+			return nil, err
+		}
+		if len(chunks) == 0 {
+			return all, nil
+		}
+		all = append(all, chunks...)
+	}
+}
+
+// failMigration removes the partial generation and releases it, then
+// reports err without retrying: a retry would start from scratch anyway.
+func (s *indexMigrationService) failMigration(
+	ctx context.Context, kb *types.KnowledgeBase, generation int64,
+	engine *retriever.CompositeRetrieveEngine, embedder embedding.Embedder, err error,
+) error {
+	logger.Errorf(ctx, "Index migration of %s (generation %d) failed: %v", kb.ID, generation, err)
+	s.discardGeneration(ctx, kb, generation, engine, embedder)
+	if abortErr := s.kbRepo.AbortIndexGeneration(ctx, kb.ID, generation); abortErr != nil &&
+		!errors.Is(abortErr, repository.ErrIndexGenerationMismatch) {
+		logger.Warnf(ctx, "Failed to release index generation %d of %s: %v", generation, kb.ID, abortErr)
+	}
+	return fmt.Errorf("index migration failed: %v: %w", err, asynq.SkipRetry)
+}
+
+// discardGeneration deletes the chunks staged for generation and, when the
+// engine is known, their index rows. Generations are never reused, so
+// everything at this generation belongs to the calling worker.
+func (s *indexMigrationService) discardGeneration(
+	ctx context.Context, kb *types.KnowledgeBase, generation int64,
+	engine *retriever.CompositeRetrieveEngine, embedder embedding.Embedder,
+) {
+	ids, err := s.chunkRepo.ListChunkIDsByIndexGeneration(ctx, kb.TenantID, kb.ID, generation)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to list staged chunks of %s: %v", kb.ID, err)
+		return
+	}
+	if engine != nil && embedder != nil {
+		for batch := range slices.Chunk(ids, indexMigrationBatchSize) {
+			if err := engine.DeleteByChunkIDList(ctx, batch, embedder.GetDimensions(), kb.Type); err != nil {
+				logger.Warnf(ctx, "Failed to delete staged index rows of %s: %v", kb.ID, err)
+			}
+		}
+	}
+	if err := s.chunkRepo.DeleteChunks(ctx, kb.TenantID, ids); err != nil {
+		logger.Warnf(ctx, "Failed to delete staged chunks of %s: %v", kb.ID, err)
+	}
+}
+
+// copyToGeneration duplicates chunks into generation under new IDs,
+// rewriting the links between them. Used when only the embeddings change.
+func copyToGeneration(source []*types.Chunk, generation int64) []*types.Chunk {
+	idMap := make(map[string]string, len(source))
+	out := make([]*types.Chunk, 0, len(source))
+	for _, src := range source {
+		c := newGenerationChunk(src, generation)
+		c.Content = src.Content
+		c.ChunkIndex = src.ChunkIndex
+		c.StartAt, c.EndAt = src.StartAt, src.EndAt
+		c.PreChunkID, c.NextChunkID, c.ParentChunkID = src.PreChunkID, src.NextChunkID, src.ParentChunkID
+		c.Metadata = src.Metadata
+		c.ImageInfo = src.ImageInfo
+		idMap[src.ID] = c.ID
+		out = append(out, c)
+	}
+	for _, c := range out {
+		c.PreChunkID = idMap[c.PreChunkID]
+		c.NextChunkID = idMap[c.NextChunkID]
+		c.ParentChunkID = idMap[c.ParentChunkID]
+	}
+	return out
+}
+
+// rechunkToGeneration re-splits the document text recovered from source
+// with cfg and writes the result into generation. Summary and image chunks
+// are carried over, image chunks re-attached to the new text chunk at
+// their old parent's position. Generated questions, table summaries and
+// context preambles belonged to the old chunks and are not carried over; the heading path,
+// table and code symbol metadata of the new chunks come from the chunker.
+// Source files are re-split by symbol whatever cfg says.
+func rechunkToGeneration(source []*types.Chunk, cfg types.ChunkingConfig, generation int64) []*types.Chunk {
+	text := reassembleChunkText(source)
+	if strings.TrimSpace(text) == "" {
+		return copyToGeneration(source, generation)
+	}
+	var first *types.Chunk
+	for _, c := range source {
+		if c.ChunkType == types.ChunkTypeText || c.ChunkType == types.ChunkTypeParentText {
+			first = c
+			break
+		}
+	}
+
+	var parents, children []*types.Chunk
+	splitCfg := buildSplitterConfigFromChunking(cfg)
+	if filePath := codeFilePath(first); filePath != "" {
+		for _, sc := range splitCodeChunks(text, filePath, splitCfg) {
+			children = appendGenerationChunk(children, first, generation, sc)
+		}
+	} else if cfg.EnableParentChild {
+		parentCfg, childCfg := buildParentChildConfigs(cfg, splitCfg)
+		result := chunker.SplitParentChild(text, parentCfg, childCfg)
+		for i, p := range result.Parents {
+			c := newGenerationChunk(first, generation)
+			c.ChunkType = types.ChunkTypeParentText
+			c.Content, c.ChunkIndex, c.StartAt, c.EndAt = p.Content, p.Seq, p.Start, p.End
+			if i > 0 {
+				parents[i-1].NextChunkID = c.ID
+				c.PreChunkID = parents[i-1].ID
+			}
+			parents = append(parents, c)
+		}
+		for _, ch := range result.Children {
+			if strings.TrimSpace(ch.Content) == "" {
+				continue
+			}
+			c := newGenerationChunk(first, generation)
+			c.ChunkType = types.ChunkTypeText
+			c.Content, c.ContextHeader, c.ChunkIndex, c.StartAt, c.EndAt = ch.Content, ch.ContextHeader, ch.Seq, ch.Start, ch.End
+			if meta := splitChunkMetadata(ch.Chunk); meta != nil {
+				_ = c.SetDocumentMetadata(meta)
+			}
+			if ch.ParentIndex >= 0 && ch.ParentIndex < len(parents) {
+				c.ParentChunkID = parents[ch.ParentIndex].ID
+			}
+			children = append(children, c)
+		}
+	} else {
+		for _, sc := range splitFlatChunks(text, cfg, splitCfg) {
+			children = appendGenerationChunk(children, first, generation, sc)
+		}
+	}
+
+	// Old text chunk ID -> new text chunk covering its start position.
+	covering := func(pos int) *types.Chunk {
+		for _, c := range children {
+			if pos >= c.StartAt && pos < c.EndAt {
+				return c
+			}
+		}
+		if len(children) > 0 {
+			return children[len(children)-1]
+		}
+		return nil
+	}
+	remap := make(map[string]*types.Chunk)
+	images := make(map[*types.Chunk][]types.ImageInfo)
+	for _, src := range source {
+		if src.ChunkType != types.ChunkTypeText {
+			continue
+		}
+		target := covering(src.StartAt)
+		if target == nil {
+			continue
+		}
+		remap[src.ID] = target
+		var infos []types.ImageInfo
+		if src.ImageInfo != "" && json.Unmarshal([]byte(src.ImageInfo), &infos) == nil {
+			images[target] = append(images[target], infos...)
+		}
+	}
+	for target, infos := range images {
+		if b, err := json.Marshal(infos); err == nil {
+			target.ImageInfo = string(b)
+		}
+	}
+
+	out := append(parents, children...)
+	for _, src := range source {
+		switch src.ChunkType {
+		case types.ChunkTypeText, types.ChunkTypeParentText:
+			continue
+		}
+		c := newGenerationChunk(src, generation)
+		c.Content, c.ChunkIndex, c.StartAt, c.EndAt = src.Content, src.ChunkIndex, src.StartAt, src.EndAt
+		c.Metadata, c.ImageInfo = src.Metadata, src.ImageInfo
+		if target, ok := remap[src.ParentChunkID]; ok {
+			c.ParentChunkID = target.ID
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// reassembleChunkText recovers the document text from its chunks. Parent
+// chunks are preferred since they tile the document; overlaps between
+// consecutive chunks are cut using the StartAt/EndAt rune offsets, and gaps
+// are padded with newlines so new chunk offsets stay comparable with the
+// old ones. When offsets are inconsistent with the content (older data),
+// contents are joined in chunk order instead.
+func reassembleChunkText(chunks []*types.Chunk) string {
+	var spans []*types.Chunk
+	for _, kind := range []types.ChunkType{types.ChunkTypeParentText, types.ChunkTypeText} {
+		for _, c := range chunks {
+			if c.ChunkType == kind {
+				spans = append(spans, c)
+			}
+		}
+		if len(spans) > 0 {
+			break
+		}
+	}
+	slices.SortStableFunc(spans, func(a, b *types.Chunk) int { return a.ChunkIndex - b.ChunkIndex })
+
+	offsetsValid := true
+	for i, c := range spans {
+		if c.EndAt-c.StartAt != len([]rune(c.Content)) || (i > 0 && c.StartAt < spans[i-1].StartAt) {
+			offsetsValid = false
+			break
+		}
+	}
+	var b strings.Builder
+	if !offsetsValid {
+		for i, c := range spans {
+			if i > 0 {
+				b.WriteString("\n\n")
+			}
+			b.WriteString(c.Content)
+		}
+		return b.String()
+	}
+	pos := 0
+	for _, c := range spans {
+		if c.StartAt > pos {
+			b.WriteString(strings.Repeat("\n", c.StartAt-pos))
+			pos = c.StartAt
+		}
+		runes := []rune(c.Content)
+		if skip := pos - c.StartAt; skip < len(runes) {
+			b.WriteString(string(runes[skip:]))
+			pos = c.EndAt
+		}
+	}
+	return b.String()
+}
+
+// newGenerationChunk returns a chunk with a fresh ID in generation that
+// inherits ownership and state from src.
+// appendGenerationChunk appends the flat text chunk built from sc to
+// chunks, linked to the previous one. Blank chunks are dropped.
+func appendGenerationChunk(chunks []*types.Chunk, first *types.Chunk, generation int64, sc types.ParsedChunk) []*types.Chunk {
+	if strings.TrimSpace(sc.Content) == "" {
+		return chunks
+	}
+	c := newGenerationChunk(first, generation)
+	c.ChunkType = types.ChunkTypeText
+	c.Content, c.ContextHeader, c.ChunkIndex, c.StartAt, c.EndAt = sc.Content, sc.ContextHeader, sc.Seq, sc.Start, sc.End
+	if sc.Metadata != nil {
+		_ = c.SetDocumentMetadata(sc.Metadata)
+	}
+	if n := len(chunks); n > 0 {
+		chunks[n-1].NextChunkID = c.ID
+		c.PreChunkID = chunks[n-1].ID
+	}
+	return append(chunks, c)
+}
+
+func newGenerationChunk(src *types.Chunk, generation int64) *types.Chunk {
+	now := time.Now()
+	return &types.Chunk{
+		ID:              uuid.New().String(),
+		TenantID:        src.TenantID,
+		KnowledgeID:     src.KnowledgeID,
+		KnowledgeBaseID: src.KnowledgeBaseID,
+		TagID:           src.TagID,
+		ChunkType:       src.ChunkType,
+		IsEnabled:       src.IsEnabled,
+		Flags:           src.Flags,
+		Status:          src.Status,
+		ContentHash:     src.ContentHash,
+		IndexGeneration: generation,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+}
+
+// generationIndexInfo builds the index rows of a rebuilt document the way
+// ingestion does: text chunks prefixed with the document title and their
+// context preamble plus their generated questions and table summaries,
+// summary and image chunks as they are. Parent chunks are context only and
+// are not indexed.
+func generationIndexInfo(knowledge *types.Knowledge, chunks []*types.Chunk) []*types.IndexInfo {
+	titlePrefix := ""
+	if t := strings.TrimSpace(knowledge.Title); t != "" {
+		titlePrefix = t + "\n"
+	}
+	infos := make([]*types.IndexInfo, 0, len(chunks))
+	add := func(c *types.Chunk, sourceID, content string) {
+		infos = append(infos, &types.IndexInfo{
+			Content:         content,
+			SourceID:        sourceID,
+			SourceType:      types.ChunkSourceType,
+			ChunkID:         c.ID,
+			KnowledgeID:     c.KnowledgeID,
+			KnowledgeBaseID: c.KnowledgeBaseID,
+			IsEnabled:       c.IsEnabled,
+		})
+	}
+	for _, c := range chunks {
+		switch c.ChunkType {
+		case types.ChunkTypeParentText:
+			continue
+		case types.ChunkTypeText:
+			add(c, c.ID, chunkIndexContent(titlePrefix, c))
+			if meta, err := c.DocumentMetadata(); err == nil && meta != nil {
+				for _, q := range meta.GeneratedQuestions {
+					add(c, fmt.Sprintf("%s-%s", c.ID, q.ID), q.Question)
+				}
+				if meta.Table != nil && meta.Table.Summary != "" {
+					add(c, tableSummarySourceID(c.ID), meta.Table.Summary)
+				}
+			}
+		default:
+			add(c, c.ID, c.Content)
+		}
+	}
+	return infos
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestReassembleChunkText(t *testing.T) {
+	// Overlapping text chunks with a gap: "abcdef" + overlap "ef" + gap.
+	chunks := []*types.Chunk{
+		{ChunkType: types.ChunkTypeText, ChunkIndex: 1, Content: "efgh", StartAt: 4, EndAt: 8},
+		{ChunkType: types.ChunkTypeText, ChunkIndex: 0, Content: "abcdef", StartAt: 0, EndAt: 6},
+		{ChunkType: types.ChunkTypeText, ChunkIndex: 2, Content: "中文", StartAt: 10, EndAt: 12},
+		{ChunkType: types.ChunkTypeImageOCR, ChunkIndex: 3, Content: "ignored"},
+	}
+	if got, want := reassembleChunkText(chunks), "abcdefgh\n\n中文"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	// Parent chunks tile the document and win over their children.
+	withParents := append(chunks, &types.Chunk{
+		ChunkType: types.ChunkTypeParentText, Content: "parent", StartAt: 0, EndAt: 6,
+	})
+	if got := reassembleChunkText(withParents); got != "parent" {
+		t.Fatalf("parent chunks not preferred: %q", got)
+	}
+
+	// Offsets that disagree with the content fall back to joining in order.
+	legacy := []*types.Chunk{
+		{ChunkType: types.ChunkTypeText, ChunkIndex: 1, Content: "second", StartAt: 0, EndAt: 0},
+		{ChunkType: types.ChunkTypeText, ChunkIndex: 0, Content: "first", StartAt: 0, EndAt: 0},
+	}
+	if got, want := reassembleChunkText(legacy), "first\n\nsecond"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestCopyToGenerationRemapsLinks(t *testing.T) {
+	source := []*types.Chunk{
+		{ID: "p", ChunkType: types.ChunkTypeParentText, NextChunkID: "p2"},
+		{ID: "p2", ChunkType: types.ChunkTypeParentText, PreChunkID: "p"},
+		{ID: "c", ChunkType: types.ChunkTypeText, ParentChunkID: "p", IsEnabled: true},
+		{ID: "img", ChunkType: types.ChunkTypeImageOCR, ParentChunkID: "c"},
+	}
+	out := copyToGeneration(source, 3)
+	byOld := make(map[string]*types.Chunk)
+	for i, c := range out {
+		if c.ID == source[i].ID || c.IndexGeneration != 3 {
+			t.Fatalf("chunk %s: id=%s generation=%d", source[i].ID, c.ID, c.IndexGeneration)
+		}
+		byOld[source[i].ID] = c
+	}
+	if byOld["p"].NextChunkID != byOld["p2"].ID || byOld["p2"].PreChunkID != byOld["p"].ID {
+		t.Fatal("pre/next links not remapped")
+	}
+	if byOld["c"].ParentChunkID != byOld["p"].ID || byOld["img"].ParentChunkID != byOld["c"].ID {
+		t.Fatal("parent links not remapped")
+	}
+	if !byOld["c"].IsEnabled {
+		t.Fatal("enabled state not carried over")
+	}
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// KBSnapshotScheduler enqueues the scheduled snapshots of knowledge bases
+// whose snapshot policy is due. Each due policy is claimed with a
+// conditional update of its next run, so replicas ticking at the same time
+// enqueue a snapshot once. Policies of deleted knowledge bases are removed.
+type KBSnapshotScheduler struct {
+	snapshotRepo     interfaces.KBSnapshotRepository
+	tenantRepo       interfaces.TenantRepository
+	knowledgeService interfaces.KnowledgeService
+	interval         time.Duration
+	now              func() time.Time
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	// started lets Stop tell "never started" apart from "running"; see
+	// AuditLogRetentionRunner.
+	started atomic.Bool
+}
+
+const (
+	// kbSnapshotScheduleInterval is the gap between checks for due
+	// policies. Policies are configured in hours, so a snapshot starts at
+	// most this long after it is due.
+	kbSnapshotScheduleInterval = 10 * time.Minute
+	// kbSnapshotScheduleStartupDelay holds the first check until after boot.
+	kbSnapshotScheduleStartupDelay = 2 * time.Minute
+	// kbSnapshotScheduleTimeout bounds a single check.
+	kbSnapshotScheduleTimeout = 5 * time.Minute
+	// kbSnapshotScheduleBatch caps the snapshots enqueued per check; the
+	// rest are picked up by the next one.
+	kbSnapshotScheduleBatch = 100
+)
+
+// NewKBSnapshotScheduler constructs the scheduler. Nothing fires until
+// Start is called.
+func NewKBSnapshotScheduler(
+	snapshotRepo interfaces.KBSnapshotRepository,
+	tenantRepo interfaces.TenantRepository,
+	knowledgeService interfaces.KnowledgeService,
+) *KBSnapshotScheduler {
+	return &KBSnapshotScheduler{
+		snapshotRepo:     snapshotRepo,
+		tenantRepo:       tenantRepo,
+		knowledgeService: knowledgeService,
+		interval:         kbSnapshotScheduleInterval,
+		now:              time.Now,
+		stopCh:           make(chan struct{}),
+		doneCh:           make(chan struct{}),
+	}
+}
+
+// Start spins up the background goroutine. Calling it more than once is a
+// no-op.
+func (r *KBSnapshotScheduler) Start(ctx context.Context) {
+	if r == nil || r.snapshotRepo == nil {
+		return
+	}
+	r.startOnce.Do(func() {
+		r.started.Store(true)
+		logger.Infof(ctx, "[kb-snapshot] starting scheduler: interval=%s", r.interval)
+		go r.loop()
+	})
+}
+
+// Stop signals the loop to exit and blocks until it returns. Idempotent.
+func (r *KBSnapshotScheduler) Stop() {
+	if r == nil || !r.started.Load() {
+		return
+	}
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+	})
+	<-r.doneCh
+}
+
+func (r *KBSnapshotScheduler) loop() {
+	defer close(r.doneCh)
+
+	startupTimer := time.NewTimer(kbSnapshotScheduleStartupDelay)
+	defer startupTimer.Stop()
+	select {
+	case <-startupTimer.C:
+	case <-r.stopCh:
+		return
+	}
+
+	r.runOnce()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.runOnce()
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+// runOnce enqueues a snapshot for every due policy it wins. Failures are
+// logged; the policy has already moved to its next run, so a failed
+// enqueue skips one scheduled snapshot rather than retrying every tick.
+func (r *KBSnapshotScheduler) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), kbSnapshotScheduleTimeout)
+	defer cancel()
+
+	now := r.now()
+	policies, err := r.snapshotRepo.ListDuePolicies(ctx, now, kbSnapshotScheduleBatch)
+	if err != nil {
+		logger.Warnf(ctx, "[kb-snapshot] failed to list due policies: %v", err)
+		return
+	}
+	enqueued := 0
+	for _, policy := range policies {
+		if r.runPolicy(ctx, policy, now) {
+			enqueued++
+		}
+	}
+	if enqueued > 0 {
+		logger.Infof(ctx, "[kb-snapshot] enqueued %d scheduled snapshots", enqueued)
+	}
+}
+
+func (r *KBSnapshotScheduler) runPolicy(ctx context.Context, policy *types.KBSnapshotPolicy, now time.Time) bool {
+	policy.Normalize()
+	won, err := r.snapshotRepo.ClaimPolicy(ctx, policy.KnowledgeBaseID, *policy.NextRunAt, now.Add(policy.Interval()))
+	if err != nil {
+		logger.Warnf(ctx, "[kb-snapshot] failed to claim policy of %s: %v", policy.KnowledgeBaseID, err)
+		return false
+	}
+	if !won {
+		return false
+	}
+
+	tenant, err := r.tenantRepo.GetTenantByID(ctx, policy.TenantID)
+	if err != nil {
+		logger.Warnf(ctx, "[kb-snapshot] failed to get tenant %d: %v", policy.TenantID, err)
+		return false
+	}
+	tenantCtx := context.WithValue(ctx, types.TenantIDContextKey, policy.TenantID)
+	tenantCtx = context.WithValue(tenantCtx, types.TenantInfoContextKey, tenant)
+	if _, err := r.knowledgeService.CreateKBSnapshot(tenantCtx, policy.KnowledgeBaseID,
+		types.KBSnapshotTriggerScheduled); err != nil {
+		if errors.Is(err, repository.ErrKnowledgeBaseNotFound) {
+			logger.Infof(ctx, "[kb-snapshot] knowledge base %s is gone, removing its policy", policy.KnowledgeBaseID)
+			if err := r.snapshotRepo.DeletePolicy(ctx, policy.KnowledgeBaseID); err != nil {
+				logger.Warnf(ctx, "[kb-snapshot] failed to remove policy of %s: %v", policy.KnowledgeBaseID, err)
+			}
+			return false
+		}
+		logger.Warnf(ctx, "[kb-snapshot] failed to snapshot %s: %v", policy.KnowledgeBaseID, err)
+		return false
+	}
+	return true
+}
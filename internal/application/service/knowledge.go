@@ -10,10 +10,12 @@ import (
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/application/repository"
+	filesvc "github.com/Tencent/WeKnora/internal/application/service/file"
 	"github.com/Tencent/WeKnora/internal/application/service/retriever"
 	"github.com/Tencent/WeKnora/internal/config"
 	werrors "github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/infrastructure/docparser"
+	"github.com/Tencent/WeKnora/internal/infrastructure/filescan"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -64,6 +66,9 @@ type knowledgeService struct {
 	// In-memory fallbacks for Lite mode (no Redis)
 	memFAQProgress      sync.Map // taskID -> *types.FAQImportProgress
 	memFAQRunningImport sync.Map // kbID -> *runningFAQImportInfo
+	memChunkImport      sync.Map // taskID -> *types.ChunkImportProgress
+	memKBClone          sync.Map // taskID -> *types.KBCloneProgress (clone and snapshot restore)
+	memKnowledgeMove    sync.Map // taskID -> *types.KnowledgeMoveProgress
 	wikiRepo            interfaces.WikiPageRepository
 	wikiService         interfaces.WikiPageService
 
@@ -72,6 +77,17 @@ type knowledgeService struct {
 	// handled because the public surface is the SpanTracker interface,
 	// which has a no-op fallback. See knowledge_span_tracker.go.
 	spanTracker SpanTracker
+
+	// uploadScanner checks uploaded files for malware before they are
+	// stored; nil when FILE_SCAN_PROVIDER is unset. See knowledge_scan.go.
+	uploadScanner filescan.Scanner
+	auditSvc      interfaces.AuditLogService
+	// versionRepo records the file versions of knowledge updated in place;
+	// nil in tests. See knowledge_version.go.
+	versionRepo interfaces.KnowledgeVersionRepository
+	// snapshotRepo stores knowledge base snapshots and their schedules. See
+	// knowledge_snapshot.go.
+	snapshotRepo interfaces.KBSnapshotRepository
 }
 
 const (
@@ -106,6 +122,10 @@ func NewKnowledgeService(
 	wikiService interfaces.WikiPageService,
 	taskPendingRepo interfaces.TaskPendingOpsRepository,
 	spanTracker SpanTracker,
+	uploadScanner filescan.Scanner,
+	auditSvc interfaces.AuditLogService,
+	versionRepo interfaces.KnowledgeVersionRepository,
+	snapshotRepo interfaces.KBSnapshotRepository,
 ) (interfaces.KnowledgeService, error) {
 	return &knowledgeService{
 		config:          config,
@@ -132,6 +152,10 @@ func NewKnowledgeService(
 		wikiService:     wikiService,
 		taskPendingRepo: taskPendingRepo,
 		spanTracker:     spanTracker,
+		uploadScanner:   uploadScanner,
+		auditSvc:        auditSvc,
+		versionRepo:     versionRepo,
+		snapshotRepo:    snapshotRepo,
 	}, nil
 }
 
@@ -584,6 +608,50 @@ func (s *knowledgeService) GetKnowledgeFile(ctx context.Context, id string) (io.
 	return file, knowledge.FileName, nil
 }
 
+// GetKnowledgeFileRange retrieves length bytes of the file associated with a
+// knowledge entry starting at offset (length <= 0 reads to the end), along
+// with its file name and total size, so downloads can be resumed and large
+// files streamed in pieces.
+func (s *knowledgeService) GetKnowledgeFileRange(ctx context.Context,
+	id string, offset, length int64,
+) (io.ReadCloser, string, int64, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	if knowledge.IsManual() {
+		meta, err := knowledge.ManualMetadata()
+		if err != nil {
+			return nil, "", 0, err
+		}
+		content := ""
+		if meta != nil {
+			content = meta.Content
+		}
+		size := int64(len(content))
+		if offset < 0 || offset >= size {
+			return nil, "", size, filesvc.ErrInvalidRange
+		}
+		end := size
+		if length > 0 && offset+length < end {
+			end = offset + length
+		}
+		filename := sanitizeManualDownloadFilename(knowledge.Title)
+		return io.NopCloser(strings.NewReader(content[offset:end])), filename, size, nil
+	}
+
+	kb, _ := s.kbService.GetKnowledgeBaseByID(ctx, knowledge.KnowledgeBaseID)
+	file, size, err := s.resolveFileServiceForPath(ctx, kb, knowledge.FilePath).
+		GetFileRange(ctx, knowledge.FilePath, offset, length)
+	if err != nil {
+		return nil, "", size, err
+	}
+
+	return file, knowledge.FileName, size, nil
+}
+
 func (s *knowledgeService) UpdateKnowledge(ctx context.Context, knowledge *types.Knowledge) error {
 	record, err := s.repo.GetKnowledgeByID(ctx, ctx.Value(types.TenantIDContextKey).(uint64), knowledge.ID)
 	if err != nil {
@@ -607,6 +675,42 @@ func (s *knowledgeService) UpdateKnowledge(ctx context.Context, knowledge *types
 	return nil
 }
 
+// UpdateKnowledgeACL replaces the ACL of a knowledge, or removes it when
+// acl lists nobody. The new ACL applies to the next retrieval.
+func (s *knowledgeService) UpdateKnowledgeACL(ctx context.Context,
+	id string, acl *types.KnowledgeACL,
+) (*types.Knowledge, error) {
+	record, err := s.repo.GetKnowledgeByID(ctx, ctx.Value(types.TenantIDContextKey).(uint64), id)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get knowledge record: %v", err)
+		return nil, err
+	}
+	normalized := &types.KnowledgeACL{}
+	if acl != nil {
+		normalized.Users = dedupPreservingOrder(acl.Users)
+		normalized.Groups = dedupPreservingOrder(acl.Groups)
+	}
+	for _, group := range normalized.Groups {
+		if group == types.KnowledgeACLGroupAPIKey || types.TenantRole(group).IsValid() {
+			continue
+		}
+		if name, ok := strings.CutPrefix(group, types.KnowledgeACLSSOGroupPrefix); ok && name != "" {
+			continue
+		}
+		return nil, werrors.NewBadRequestError("groups 只能是租户角色、api_key 或 sso:<外部组名>")
+	}
+	if err := record.SetACL(normalized); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateKnowledgeColumn(ctx, record.ID, "metadata", record.Metadata); err != nil {
+		logger.Errorf(ctx, "Failed to update knowledge ACL: %v", err)
+		return nil, err
+	}
+	logger.Infof(ctx, "Knowledge ACL updated, ID: %s, users: %d, groups: %v",
+		record.ID, len(normalized.Users), normalized.Groups)
+	return record, nil
+}
+
 // GetKnowledgeBatch retrieves multiple knowledge entries by their IDs
 func (s *knowledgeService) GetKnowledgeBatch(ctx context.Context,
 	tenantID uint64, ids []string,
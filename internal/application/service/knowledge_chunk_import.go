@@ -0,0 +1,666 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/time/rate"
+)
+
+const (
+	chunkImportProgressKeyPrefix = "chunk_import_progress:"
+	chunkImportProgressTTL       = 24 * time.Hour
+	// chunkImportTimeout bounds one run of the task; a retry resumes after
+	// the last document written.
+	chunkImportTimeout = 6 * time.Hour
+	// chunkImportMaxLineBytes caps one JSONL line (a chunk plus its vector).
+	chunkImportMaxLineBytes = 8 << 20
+	// chunkImportMetadataKey stores the source document ID on the imported
+	// knowledge so importing it again replaces its chunks.
+	chunkImportMetadataKey = "import_document_id"
+)
+
+// StartChunkImport stores an uploaded JSONL file of pre-chunked (and
+// optionally pre-embedded) documents and enqueues its import in the bulk
+// lane. batchSize bounds the rows per write, rateLimit the chunks written
+// per second (0 = unlimited).
+func (s *knowledgeService) StartChunkImport(ctx context.Context, kbID string,
+	file *multipart.FileHeader, batchSize, rateLimit int, channel string,
+) (*types.ChunkImportProgress, error) {
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get knowledge base: %v", err)
+		return nil, err
+	}
+	if kb.Type != "" && kb.Type != types.KnowledgeBaseTypeDocument {
+		return nil, werrors.NewBadRequestError("仅文档类知识库支持导入分块")
+	}
+	if ext := strings.ToLower(filepath.Ext(file.Filename)); ext != ".jsonl" && ext != ".ndjson" {
+		return nil, werrors.NewBadRequestError("仅支持 JSONL 格式的分块文件")
+	}
+	if batchSize <= 0 {
+		batchSize = types.DefaultChunkImportBatchSize
+	}
+	if batchSize > types.MaxChunkImportBatchSize {
+		batchSize = types.MaxChunkImportBatchSize
+	}
+	if rateLimit < 0 {
+		rateLimit = 0
+	}
+
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenantInfo.StorageQuota > 0 && tenantInfo.StorageUsed >= tenantInfo.StorageQuota {
+		logger.Error(ctx, "Storage quota exceeded")
+		return nil, types.NewStorageQuotaExceededError()
+	}
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+
+	taskID := uuid.New().String()
+	filePath, err := s.fileSvc.SaveFile(ctx, file, tenantID, taskID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to save chunk import file: %v", err)
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	progress := &types.ChunkImportProgress{
+		TaskID:    taskID,
+		KBID:      kbID,
+		Status:    types.KBCloneStatusPending,
+		FileSize:  file.Size,
+		Message:   "任务已提交，等待处理",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.saveChunkImportProgress(ctx, progress); err != nil {
+		logger.Errorf(ctx, "Failed to save chunk import progress: %v", err)
+		return nil, err
+	}
+
+	payload := types.ChunkImportPayload{
+		TenantID:  tenantID,
+		TaskID:    taskID,
+		KBID:      kbID,
+		FilePath:  filePath,
+		FileSize:  file.Size,
+		BatchSize: batchSize,
+		RateLimit: rateLimit,
+		Channel:   defaultChannel(channel),
+	}
+	langfuse.InjectTracing(ctx, &payload)
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal chunk import payload: %w", err)
+	}
+	task := asynq.NewTask(types.TypeChunkImport, payloadBytes,
+		asynq.TaskID(taskID),
+		asynq.Queue(types.QueueBulk),
+		asynq.MaxRetry(3),
+		asynq.Timeout(chunkImportTimeout),
+	)
+	info, err := s.task.Enqueue(task)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to enqueue chunk import task: %v", err)
+		return nil, fmt.Errorf("failed to enqueue chunk import task: %w", err)
+	}
+	logger.Infof(ctx, "Enqueued chunk import task: id=%s queue=%s kb_id=%s size=%d",
+		info.ID, info.Queue, kbID, file.Size)
+	return progress, nil
+}
+
+// GetChunkImportProgress retrieves the progress of a chunk import task.
+func (s *knowledgeService) GetChunkImportProgress(ctx context.Context, taskID string) (*types.ChunkImportProgress, error) {
+	if s.redisClient == nil {
+		if v, ok := s.memChunkImport.Load(taskID); ok {
+			return v.(*types.ChunkImportProgress), nil
+		}
+		return nil, werrors.NewNotFoundError("Chunk import task not found")
+	}
+	data, err := s.redisClient.Get(ctx, chunkImportProgressKeyPrefix+taskID).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, werrors.NewNotFoundError("Chunk import task not found")
+		}
+		return nil, fmt.Errorf("failed to get chunk import progress from Redis: %w", err)
+	}
+	var progress types.ChunkImportProgress
+	if err := json.Unmarshal(data, &progress); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal chunk import progress: %w", err)
+	}
+	return &progress, nil
+}
+
+func (s *knowledgeService) saveChunkImportProgress(ctx context.Context, progress *types.ChunkImportProgress) error {
+	progress.UpdatedAt = time.Now().Unix()
+	if s.redisClient == nil {
+		cp := *progress
+		cp.Errors = append([]types.ChunkImportError(nil), progress.Errors...)
+		s.memChunkImport.Store(progress.TaskID, &cp)
+		return nil
+	}
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chunk import progress: %w", err)
+	}
+	return s.redisClient.Set(ctx, chunkImportProgressKeyPrefix+progress.TaskID, data, chunkImportProgressTTL).Err()
+}
+
+// ProcessChunkImport handles chunk:import tasks. Documents are written one
+// at a time; progress records the file offset after the last document
+// written so a retried task resumes there instead of starting over.
+func (s *knowledgeService) ProcessChunkImport(ctx context.Context, t *asynq.Task) error {
+	var payload types.ChunkImportPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "Failed to unmarshal chunk import payload: %v", err)
+		return nil
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get tenant %d: %v", payload.TenantID, err)
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+
+	progress, err := s.GetChunkImportProgress(ctx, payload.TaskID)
+	if err != nil {
+		logger.Warnf(ctx, "Chunk import progress missing for %s, starting over: %v", payload.TaskID, err)
+		progress = &types.ChunkImportProgress{
+			TaskID: payload.TaskID, KBID: payload.KBID, FileSize: payload.FileSize,
+			CreatedAt: time.Now().Unix(),
+		}
+	}
+	if progress.Status == types.KBCloneStatusCompleted {
+		return nil
+	}
+
+	runErr := s.runChunkImport(ctx, &payload, progress)
+	if runErr == nil {
+		progress.Status = types.KBCloneStatusCompleted
+		progress.Progress = 100
+		progress.Message = fmt.Sprintf("导入完成：%d 个文档，%d 个分块，%d 个文档失败",
+			progress.Documents, progress.Chunks, progress.FailedDocuments)
+	} else if isFinalAsynqAttempt(ctx) {
+		progress.Status = types.KBCloneStatusFailed
+		progress.Error = runErr.Error()
+		progress.Message = "导入失败"
+	} else {
+		progress.Error = runErr.Error()
+		progress.Message = "导入中断，等待重试"
+	}
+	if err := s.saveChunkImportProgress(ctx, progress); err != nil {
+		logger.Warnf(ctx, "Failed to save chunk import progress: %v", err)
+	}
+	if runErr == nil || isFinalAsynqAttempt(ctx) {
+		if err := s.fileSvc.DeleteFile(ctx, payload.FilePath); err != nil {
+			logger.Warnf(ctx, "Failed to delete chunk import file %s: %v", payload.FilePath, err)
+		}
+	}
+	return runErr
+}
+
+// chunkImportTarget bundles what writing a document needs.
+type chunkImportTarget struct {
+	kb             *types.KnowledgeBase
+	engine         *retriever.CompositeRetrieveEngine // nil when the KB keeps no index
+	embeddingModel embedding.Embedder
+	dimension      int
+	limiter        *rate.Limiter // nil when unlimited
+	batchSize      int
+	channel        string
+}
+
+func (s *knowledgeService) runChunkImport(ctx context.Context,
+	payload *types.ChunkImportPayload, progress *types.ChunkImportProgress,
+) error {
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, payload.KBID)
+	if err != nil {
+		return fmt.Errorf("failed to get knowledge base: %w", err)
+	}
+	target := &chunkImportTarget{kb: kb, batchSize: payload.BatchSize, channel: payload.Channel}
+	if target.batchSize <= 0 {
+		target.batchSize = types.DefaultChunkImportBatchSize
+	}
+	if payload.RateLimit > 0 {
+		target.limiter = rate.NewLimiter(rate.Limit(payload.RateLimit), max(payload.RateLimit, target.batchSize))
+	}
+	if kb.NeedsEmbeddingModel() {
+		target.embeddingModel, err = s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+		if err != nil {
+			return fmt.Errorf("failed to get embedding model: %w", err)
+		}
+		if kb.IndexingStrategy.VectorEnabled {
+			target.dimension = target.embeddingModel.GetDimensions()
+		}
+		target.engine, err = retriever.CreateRetrieveEngineForKB(ctx, s.retrieveEngine, s.ownership, payload.TenantID, kb.VectorStoreID)
+		if err != nil {
+			return fmt.Errorf("failed to init retrieve engine: %w", err)
+		}
+	}
+
+	file, err := s.fileSvc.GetFile(ctx, payload.FilePath)
+	if err != nil {
+		return fmt.Errorf("failed to open chunk import file: %w", err)
+	}
+	defer file.Close()
+	reader, err := newChunkImportReader(file, progress.BytesRead, progress.Lines)
+	if err != nil {
+		return fmt.Errorf("failed to resume chunk import: %w", err)
+	}
+	reader.dimension = target.dimension
+
+	progress.Status = types.KBCloneStatusProcessing
+	progress.Error = ""
+	progress.Message = "正在导入"
+	lastSave := time.Time{}
+	for {
+		doc, err := reader.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		for _, e := range reader.takeErrors() {
+			progress.AddError(e)
+		}
+		if doc != nil {
+			if len(doc.errors) > 0 {
+				progress.FailedDocuments++
+				for _, e := range doc.errors {
+					progress.AddError(e)
+				}
+			} else {
+				if err := s.writeImportedDocument(ctx, target, doc); err != nil {
+					logger.Errorf(ctx, "Chunk import: failed to write document %s: %v", doc.id, err)
+					return err
+				}
+				progress.Documents++
+				progress.Chunks += len(doc.records)
+			}
+		}
+		progress.BytesRead, progress.Lines = reader.committed()
+		if progress.FileSize > 0 {
+			progress.Progress = int(progress.BytesRead * 100 / progress.FileSize)
+			if progress.Progress > 99 {
+				progress.Progress = 99
+			}
+		}
+		if time.Since(lastSave) >= time.Second {
+			lastSave = time.Now()
+			if err := s.saveChunkImportProgress(ctx, progress); err != nil {
+				logger.Warnf(ctx, "Failed to save chunk import progress: %v", err)
+			}
+		}
+	}
+	for _, e := range reader.takeErrors() {
+		progress.AddError(e)
+	}
+	progress.BytesRead, progress.Lines = reader.committed()
+	return nil
+}
+
+// writeImportedDocument writes one document's chunks and index rows,
+// replacing an earlier import of the same document ID.
+func (s *knowledgeService) writeImportedDocument(ctx context.Context,
+	target *chunkImportTarget, doc *chunkImportDocument,
+) error {
+	kb := target.kb
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	first := doc.records[0]
+
+	metadata := map[string]string{}
+	for k, v := range first.Metadata {
+		metadata[k] = v
+	}
+	delete(metadata, types.KnowledgeACLMetadataKey)
+	metadata[chunkImportMetadataKey] = doc.id
+	metadataBytes, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	title := strings.TrimSpace(first.Title)
+	if title == "" {
+		title = doc.id
+	}
+
+	knowledge, err := s.repo.FindByMetadataKey(ctx, tenantID, kb.ID, chunkImportMetadataKey, doc.id)
+	if err != nil {
+		return fmt.Errorf("failed to look up imported document: %w", err)
+	}
+	now := time.Now()
+	if knowledge != nil && knowledge.Type == types.KnowledgeTypeImported {
+		if err := s.cleanupKnowledgeResources(ctx, knowledge); err != nil {
+			return fmt.Errorf("failed to clean up previous import: %w", err)
+		}
+		knowledge.Title = title
+		knowledge.Source = first.Source
+		// A re-import replaces the metadata but keeps the document's ACL
+		acl := knowledge.ACL()
+		knowledge.Metadata = types.JSON(metadataBytes)
+		if err := knowledge.SetACL(acl); err != nil {
+			return err
+		}
+		knowledge.ParseStatus = types.ParseStatusProcessing
+		knowledge.ErrorMessage = ""
+		knowledge.UpdatedAt = now
+		if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+			return err
+		}
+	} else {
+		knowledge = &types.Knowledge{
+			ID:               uuid.New().String(),
+			TenantID:         tenantID,
+			KnowledgeBaseID:  kb.ID,
+			Type:             types.KnowledgeTypeImported,
+			Channel:          target.channel,
+			Title:            title,
+			Source:           first.Source,
+			Metadata:         types.JSON(metadataBytes),
+			ParseStatus:      types.ParseStatusProcessing,
+			EnableStatus:     "disabled",
+			EmbeddingModelID: kb.EmbeddingModelID,
+			CreatedAt:        now,
+			UpdatedAt:        now,
+		}
+		if err := s.repo.CreateKnowledge(ctx, knowledge); err != nil {
+			return err
+		}
+	}
+
+	chunks := buildImportedChunks(knowledge, doc.records)
+	storageSize, err := s.writeImportedChunks(ctx, target, knowledge, doc.records, chunks)
+	if err != nil {
+		if cerr := s.chunkService.DeleteChunksByKnowledgeID(ctx, knowledge.ID); cerr != nil {
+			logger.Warnf(ctx, "Chunk import: failed to clean up chunks of %s: %v", knowledge.ID, cerr)
+		}
+		if target.engine != nil {
+			if cerr := target.engine.DeleteByKnowledgeIDList(ctx, []string{knowledge.ID},
+				target.embeddingModel.GetDimensions(), knowledge.Type); cerr != nil {
+				logger.Warnf(ctx, "Chunk import: failed to clean up index of %s: %v", knowledge.ID, cerr)
+			}
+		}
+		knowledge.ParseStatus = types.ParseStatusFailed
+		knowledge.ErrorMessage = err.Error()
+		knowledge.UpdatedAt = time.Now()
+		if uerr := s.repo.UpdateKnowledge(ctx, knowledge); uerr != nil {
+			logger.Warnf(ctx, "Chunk import: failed to mark %s failed: %v", knowledge.ID, uerr)
+		}
+		return err
+	}
+
+	processedAt := time.Now()
+	knowledge.ParseStatus = types.ParseStatusCompleted
+	knowledge.SummaryStatus = types.SummaryStatusNone
+	knowledge.EnableStatus = "enabled"
+	knowledge.StorageSize = storageSize
+	knowledge.ProcessedAt = &processedAt
+	knowledge.UpdatedAt = processedAt
+	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
+		return err
+	}
+	if storageSize > 0 {
+		if err := s.tenantRepo.AdjustStorageUsed(ctx, tenantID, storageSize); err != nil {
+			logger.Warnf(ctx, "Chunk import: failed to update tenant storage used: %v", err)
+		}
+	}
+	return nil
+}
+
+// writeImportedChunks writes chunks and their index rows in batches,
+// honouring the rate limit, and returns the estimated index storage size.
+func (s *knowledgeService) writeImportedChunks(ctx context.Context, target *chunkImportTarget,
+	knowledge *types.Knowledge, records []types.ChunkImportRecord, chunks []*types.Chunk,
+) (int64, error) {
+	var storageSize int64
+	for start := 0; start < len(chunks); start += target.batchSize {
+		end := min(start+target.batchSize, len(chunks))
+		if target.limiter != nil {
+			if err := target.limiter.WaitN(ctx, end-start); err != nil {
+				return 0, err
+			}
+		}
+		if err := s.chunkService.CreateChunks(ctx, chunks[start:end]); err != nil {
+			return 0, fmt.Errorf("failed to create chunks: %w", err)
+		}
+		if target.engine == nil {
+			continue
+		}
+
+		var embedded, unembedded []*types.IndexInfo
+		vectors := make(map[string][]float32)
+		for i := start; i < end; i++ {
+			info := &types.IndexInfo{
+				Content:         chunks[i].Content,
+				SourceID:        chunks[i].ID,
+				SourceType:      types.ChunkSourceType,
+				ChunkID:         chunks[i].ID,
+				KnowledgeID:     knowledge.ID,
+				KnowledgeBaseID: knowledge.KnowledgeBaseID,
+				KnowledgeType:   knowledge.Type,
+				IsEnabled:       true,
+			}
+			if target.dimension > 0 && len(records[i].Embedding) > 0 {
+				vectors[info.SourceID] = records[i].Embedding
+				embedded = append(embedded, info)
+			} else {
+				unembedded = append(unembedded, info)
+			}
+		}
+		if len(embedded) > 0 {
+			if err := target.engine.BatchIndexEmbedded(ctx, target.kb.EmbeddingModelID, embedded, vectors); err != nil {
+				return 0, fmt.Errorf("failed to save embedded chunks: %w", err)
+			}
+		}
+		if len(unembedded) > 0 {
+			if err := target.engine.BatchIndex(ctx, target.embeddingModel, unembedded); err != nil {
+				return 0, fmt.Errorf("failed to index chunks: %w", err)
+			}
+		}
+		storageSize += target.engine.EstimateStorageSize(ctx, target.embeddingModel, append(embedded, unembedded...))
+	}
+	return storageSize, nil
+}
+
+// buildImportedChunks turns a document's records into linked text chunks,
+// ordered by chunk_index (file order for records without one).
+func buildImportedChunks(knowledge *types.Knowledge, records []types.ChunkImportRecord) []*types.Chunk {
+	sort.SliceStable(records, func(i, j int) bool {
+		a, b := records[i].ChunkIndex, records[j].ChunkIndex
+		if a == nil || b == nil {
+			return false
+		}
+		return *a < *b
+	})
+	now := time.Now()
+	chunks := make([]*types.Chunk, len(records))
+	offset := 0
+	for i, r := range records {
+		length := utf8.RuneCountInString(r.Content)
+		chunks[i] = &types.Chunk{
+			ID:              uuid.New().String(),
+			TenantID:        knowledge.TenantID,
+			KnowledgeID:     knowledge.ID,
+			KnowledgeBaseID: knowledge.KnowledgeBaseID,
+			Content:         r.Content,
+			ChunkIndex:      i,
+			IsEnabled:       true,
+			StartAt:         offset,
+			EndAt:           offset + length,
+			ChunkType:       types.ChunkTypeText,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		offset += length
+		if i > 0 {
+			chunks[i-1].NextChunkID = chunks[i].ID
+			chunks[i].PreChunkID = chunks[i-1].ID
+		}
+	}
+	return chunks
+}
+
+// chunkImportDocument is the contiguous run of lines of one document.
+type chunkImportDocument struct {
+	id      string
+	records []types.ChunkImportRecord
+	errors  []types.ChunkImportError
+}
+
+type chunkImportLine struct {
+	number int
+	// start and startLine locate the line so a resumed reader can read it
+	// again.
+	start     int64
+	startLine int
+	record    types.ChunkImportRecord
+}
+
+// chunkImportReader groups JSONL lines into documents. Offsets and line
+// numbers are tracked so the caller can record where the last complete
+// document ended and resume there.
+type chunkImportReader struct {
+	r         *bufio.Reader
+	offset    int64
+	line      int
+	dimension int
+	pending   *chunkImportLine
+	seen      map[string]bool
+	errs      []types.ChunkImportError
+	// doneOffset / doneLine point just past the last document returned.
+	doneOffset int64
+	doneLine   int
+}
+
+// newChunkImportReader starts reading at offset, which must be a document
+// boundary previously reported by committed; line is its line number.
+func newChunkImportReader(r io.Reader, offset int64, line int) (*chunkImportReader, error) {
+	if offset > 0 {
+		if _, err := io.CopyN(io.Discard, r, offset); err != nil {
+			return nil, err
+		}
+	}
+	return &chunkImportReader{
+		r:          bufio.NewReaderSize(r, 1<<20),
+		offset:     offset,
+		line:       line,
+		seen:       make(map[string]bool),
+		doneOffset: offset,
+		doneLine:   line,
+	}, nil
+}
+
+// committed returns the offset and line number just past the last document
+// returned by next.
+func (c *chunkImportReader) committed() (int64, int) {
+	return c.doneOffset, c.doneLine
+}
+
+// takeErrors returns the errors of lines that belong to no document
+// (unparseable JSON, missing document_id).
+func (c *chunkImportReader) takeErrors() []types.ChunkImportError {
+	errs := c.errs
+	c.errs = nil
+	return errs
+}
+
+// readLine returns the next non-blank line, or io.EOF.
+func (c *chunkImportReader) readLine() (*chunkImportLine, error) {
+	for {
+		raw, err := c.r.ReadBytes('\n')
+		if len(raw) == 0 && err != nil {
+			return nil, err
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		l := &chunkImportLine{number: c.line + 1, start: c.offset, startLine: c.line}
+		c.offset += int64(len(raw))
+		c.line++
+		text := strings.TrimSpace(string(raw))
+		if text == "" {
+			continue
+		}
+		if len(raw) > chunkImportMaxLineBytes {
+			c.errs = append(c.errs, types.ChunkImportError{Line: c.line,
+				Message: fmt.Sprintf("line exceeds %d bytes", chunkImportMaxLineBytes)})
+			continue
+		}
+		if err := json.Unmarshal([]byte(text), &l.record); err != nil {
+			c.errs = append(c.errs, types.ChunkImportError{Line: c.line, Message: "invalid JSON: " + err.Error()})
+			continue
+		}
+		if strings.TrimSpace(l.record.DocumentID) == "" {
+			c.errs = append(c.errs, types.ChunkImportError{Line: c.line, Message: "document_id is required"})
+			continue
+		}
+		return l, nil
+	}
+}
+
+// next returns the next document. Lines that fail validation are reported
+// on the document, which the caller then skips as a whole.
+func (c *chunkImportReader) next() (*chunkImportDocument, error) {
+	first := c.pending
+	c.pending = nil
+	if first == nil {
+		var err error
+		first, err = c.readLine()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				c.doneOffset, c.doneLine = c.offset, c.line
+			}
+			return nil, err
+		}
+	}
+
+	doc := &chunkImportDocument{id: first.record.DocumentID}
+	if c.seen[doc.id] {
+		doc.errors = append(doc.errors, types.ChunkImportError{Line: first.number, DocumentID: doc.id,
+			Message: "lines of a document must be contiguous"})
+	}
+	c.seen[doc.id] = true
+	c.add(doc, first)
+	for {
+		l, err := c.readLine()
+		if errors.Is(err, io.EOF) {
+			c.doneOffset, c.doneLine = c.offset, c.line
+			return doc, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if l.record.DocumentID != doc.id {
+			c.pending = l
+			c.doneOffset, c.doneLine = l.start, l.startLine
+			return doc, nil
+		}
+		c.add(doc, l)
+	}
+}
+
+func (c *chunkImportReader) add(doc *chunkImportDocument, l *chunkImportLine) {
+	if err := l.record.Validate(c.dimension); err != nil {
+		doc.errors = append(doc.errors, types.ChunkImportError{Line: l.number, DocumentID: doc.id, Message: err.Error()})
+	}
+	doc.records = append(doc.records, l.record)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func readAllImportDocs(t *testing.T, r *chunkImportReader) ([]*chunkImportDocument, []types.ChunkImportError) {
+	t.Helper()
+	var docs []*chunkImportDocument
+	var errs []types.ChunkImportError
+	for {
+		doc, err := r.next()
+		errs = append(errs, r.takeErrors()...)
+		if errors.Is(err, io.EOF) {
+			return docs, errs
+		}
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		docs = append(docs, doc)
+	}
+}
+
+func TestChunkImportReader_GroupsDocuments(t *testing.T) {
+	input := strings.Join([]string{
+		`{"document_id":"a","content":"a1","embedding":[0.1,0.2]}`,
+		`{"document_id":"a","content":"a2","embedding":[0.3,0.4]}`,
+		``,
+		`not json`,
+		`{"document_id":"b","content":"b1","embedding":[0.1]}`,
+		`{"document_id":"c","content":"c1"}`,
+		`{"document_id":"a","content":"a3"}`,
+	}, "\n")
+	r, err := newChunkImportReader(strings.NewReader(input), 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.dimension = 2
+	docs, errs := readAllImportDocs(t, r)
+
+	if len(docs) != 4 {
+		t.Fatalf("got %d documents, want 4", len(docs))
+	}
+	if docs[0].id != "a" || len(docs[0].records) != 2 || len(docs[0].errors) != 0 {
+		t.Errorf("doc a = %+v", docs[0])
+	}
+	if len(docs[1].errors) != 1 || !strings.Contains(docs[1].errors[0].Message, "dimension") ||
+		docs[1].errors[0].Line != 5 {
+		t.Errorf("doc b errors = %+v", docs[1].errors)
+	}
+	if docs[2].id != "c" || len(docs[2].errors) != 0 {
+		t.Errorf("doc c = %+v", docs[2])
+	}
+	if len(docs[3].errors) == 0 || !strings.Contains(docs[3].errors[0].Message, "contiguous") {
+		t.Errorf("repeated doc a errors = %+v", docs[3].errors)
+	}
+	if len(errs) != 1 || errs[0].Line != 4 {
+		t.Errorf("line errors = %+v", errs)
+	}
+	if off, lines := r.committed(); off != int64(len(input)) || lines != 7 {
+		t.Errorf("committed = %d/%d, want %d/7", off, lines, len(input))
+	}
+}
+
+func TestChunkImportReader_ResumesAtDocumentBoundary(t *testing.T) {
+	input := `{"document_id":"a","content":"a1"}
+{"document_id":"a","content":"a2"}
+
+{"document_id":"b","content":"b1"}
+{"document_id":"b","content":"b2"}
+`
+	r, _ := newChunkImportReader(strings.NewReader(input), 0, 0)
+	if _, err := r.next(); err != nil {
+		t.Fatal(err)
+	}
+	offset, line := r.committed()
+	if line != 3 {
+		t.Fatalf("committed line = %d, want 3", line)
+	}
+
+	resumed, err := newChunkImportReader(strings.NewReader(input), offset, line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs, _ := readAllImportDocs(t, resumed)
+	if len(docs) != 1 || docs[0].id != "b" || len(docs[0].records) != 2 {
+		t.Fatalf("resumed documents = %+v", docs)
+	}
+	if docs[0].records[1].Content != "b2" {
+		t.Errorf("second chunk = %q", docs[0].records[1].Content)
+	}
+}
+
+func TestBuildImportedChunks(t *testing.T) {
+	one, zero := 1, 0
+	k := &types.Knowledge{ID: "k1", TenantID: 1, KnowledgeBaseID: "kb1"}
+	records := []types.ChunkImportRecord{
+		{DocumentID: "d", Content: "世界", ChunkIndex: &one},
+		{DocumentID: "d", Content: "你好", ChunkIndex: &zero},
+	}
+	chunks := buildImportedChunks(k, records)
+	if chunks[0].Content != "你好" || chunks[1].Content != "世界" {
+		t.Fatalf("chunks not ordered by chunk_index: %q %q", chunks[0].Content, chunks[1].Content)
+	}
+	if records[0].Content != "你好" {
+		t.Errorf("records must be reordered with the chunks")
+	}
+	if chunks[1].StartAt != 2 || chunks[1].EndAt != 4 || chunks[1].ChunkIndex != 1 {
+		t.Errorf("chunk 1 offsets = %d-%d index %d", chunks[1].StartAt, chunks[1].EndAt, chunks[1].ChunkIndex)
+	}
+	if chunks[0].NextChunkID != chunks[1].ID || chunks[1].PreChunkID != chunks[0].ID {
+		t.Errorf("chunks not linked")
+	}
+}
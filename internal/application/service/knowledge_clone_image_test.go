@@ -36,6 +36,10 @@ func (c *countingFileService) GetFile(ctx context.Context, filePath string) (io.
 	return nil, errors.New("not implemented")
 }
 
+func (c *countingFileService) GetFileRange(ctx context.Context, filePath string, offset, length int64) (io.ReadCloser, int64, error) {
+	return nil, 0, errors.New("not implemented")
+}
+
 func (c *countingFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
 	return filePath, nil
 }
@@ -797,6 +797,11 @@ func (s *knowledgeService) getOrCreateFAQKnowledge(ctx context.Context, kb *type
 
 // saveKBCloneProgress saves the KB clone progress to Redis
 func (s *knowledgeService) saveKBCloneProgress(ctx context.Context, progress *types.KBCloneProgress) error {
+	if s.redisClient == nil {
+		cp := *progress
+		s.memKBClone.Store(progress.TaskID, &cp)
+		return nil
+	}
 	key := getKBCloneProgressKey(progress.TaskID)
 	data, err := json.Marshal(progress)
 	if err != nil {
@@ -812,6 +817,14 @@ func (s *knowledgeService) SaveKBCloneProgress(ctx context.Context, progress *ty
 
 // GetKBCloneProgress retrieves the progress of a knowledge base clone task
 func (s *knowledgeService) GetKBCloneProgress(ctx context.Context, taskID string) (*types.KBCloneProgress, error) {
+	if s.redisClient == nil {
+		v, ok := s.memKBClone.Load(taskID)
+		if !ok {
+			return nil, werrors.NewNotFoundError("KB clone task not found")
+		}
+		cp := *v.(*types.KBCloneProgress)
+		return &cp, nil
+	}
 	key := getKBCloneProgressKey(taskID)
 	data, err := s.redisClient.Get(ctx, key).Bytes()
 	if err != nil {
@@ -838,6 +851,11 @@ func getKnowledgeMoveProgressKey(taskID string) string {
 }
 
 func (s *knowledgeService) saveKnowledgeMoveProgress(ctx context.Context, progress *types.KnowledgeMoveProgress) error {
+	if s.redisClient == nil {
+		cp := *progress
+		s.memKnowledgeMove.Store(progress.TaskID, &cp)
+		return nil
+	}
 	key := getKnowledgeMoveProgressKey(progress.TaskID)
 	data, err := json.Marshal(progress)
 	if err != nil {
@@ -853,6 +871,14 @@ func (s *knowledgeService) SaveKnowledgeMoveProgress(ctx context.Context, progre
 
 // GetKnowledgeMoveProgress retrieves the progress of a knowledge move task
 func (s *knowledgeService) GetKnowledgeMoveProgress(ctx context.Context, taskID string) (*types.KnowledgeMoveProgress, error) {
+	if s.redisClient == nil {
+		v, ok := s.memKnowledgeMove.Load(taskID)
+		if !ok {
+			return nil, werrors.NewNotFoundError("Knowledge move task not found")
+		}
+		cp := *v.(*types.KnowledgeMoveProgress)
+		return &cp, nil
+	}
 	key := getKnowledgeMoveProgressKey(taskID)
 	data, err := s.redisClient.Get(ctx, key).Bytes()
 	if err != nil {
@@ -892,8 +918,9 @@ func (s *knowledgeService) ProcessKnowledgeMove(ctx context.Context, t *asynq.Ta
 	maxRetry, _ := asynq.GetMaxRetry(ctx)
 	isLastRetry := retryCount >= maxRetry
 
-	logger.Infof(ctx, "ProcessKnowledgeMove: task=%s, source=%s, target=%s, mode=%s, count=%d, retry=%d/%d",
-		payload.TaskID, payload.SourceKBID, payload.TargetKBID, payload.Mode, len(payload.KnowledgeIDs), retryCount, maxRetry)
+	logger.Infof(ctx, "ProcessKnowledgeMove: task=%s, source=%s, target=%s, mode=%s, count=%d, merge=%v, retry=%d/%d",
+		payload.TaskID, payload.SourceKBID, payload.TargetKBID, payload.Mode, len(payload.KnowledgeIDs), payload.Merge,
+		retryCount, maxRetry)
 
 	// Helper function to handle errors - only mark as failed on last retry
 	handleError := func(progress *types.KnowledgeMoveProgress, err error, message string) {
@@ -943,10 +970,45 @@ func (s *knowledgeService) ProcessKnowledgeMove(ctx context.Context, t *asynq.Ta
 		return err
 	}
 
-	// Process each knowledge item
-	for i, knowledgeID := range payload.KnowledgeIDs {
-		err := s.moveOneKnowledge(ctx, knowledgeID, sourceKB, targetKB, payload.Mode)
+	// A merge moves everything the source holds when the task runs, so a
+	// retry picks up whatever the previous attempt left behind.
+	knowledgeIDs := payload.KnowledgeIDs
+	if payload.Merge {
+		list, err := s.repo.ListKnowledgeByKnowledgeBaseID(ctx, payload.TenantID, sourceKB.ID)
 		if err != nil {
+			handleError(progress, err, "Failed to list source knowledge")
+			return err
+		}
+		knowledgeIDs = make([]string, 0, len(list))
+		for _, k := range list {
+			knowledgeIDs = append(knowledgeIDs, k.ID)
+		}
+		progress.Total = len(knowledgeIDs)
+	}
+
+	// Entries whose file the target already holds are not moved; the
+	// source copy is deleted instead.
+	var duplicates map[string]string
+	if payload.Merge || payload.Dedupe {
+		duplicates, err = s.repo.ListDuplicateKnowledgeIDs(ctx, payload.TenantID, sourceKB.ID, targetKB.ID)
+		if err != nil {
+			handleError(progress, err, "Failed to find duplicate knowledge")
+			return err
+		}
+	}
+
+	// Process each knowledge item
+	tagIDMapping := make(map[string]string)
+	for i, knowledgeID := range knowledgeIDs {
+		if targetID, ok := duplicates[knowledgeID]; ok {
+			logger.Infof(ctx, "ProcessKnowledgeMove: knowledge %s duplicates %s in target, deleting it", knowledgeID, targetID)
+			if err := s.DeleteKnowledge(ctx, knowledgeID); err != nil {
+				logger.Errorf(ctx, "ProcessKnowledgeMove: failed to delete duplicate knowledge %s: %v", knowledgeID, err)
+				progress.Failed++
+			} else {
+				progress.Duplicates++
+			}
+		} else if err := s.moveOneKnowledge(ctx, knowledgeID, sourceKB, targetKB, payload.Mode, tagIDMapping); err != nil {
 			logger.Errorf(ctx, "ProcessKnowledgeMove: failed to move knowledge %s: %v", knowledgeID, err)
 			progress.Failed++
 		}
@@ -965,7 +1027,8 @@ func (s *knowledgeService) ProcessKnowledgeMove(ctx context.Context, t *asynq.Ta
 		progress.Message = fmt.Sprintf("Knowledge move failed: all %d items failed", progress.Total)
 	} else {
 		progress.Status = types.KBCloneStatusCompleted
-		progress.Message = fmt.Sprintf("Knowledge move completed: %d/%d succeeded", progress.Processed-progress.Failed, progress.Total)
+		progress.Message = fmt.Sprintf("Knowledge move completed: %d/%d succeeded, %d duplicates removed",
+			progress.Processed-progress.Failed-progress.Duplicates, progress.Total, progress.Duplicates)
 	}
 	progress.Progress = 100
 	progress.UpdatedAt = time.Now().Unix()
@@ -976,11 +1039,13 @@ func (s *knowledgeService) ProcessKnowledgeMove(ctx context.Context, t *asynq.Ta
 }
 
 // moveOneKnowledge moves a single knowledge item from source KB to target KB.
+// tagIDMapping caches source tag IDs resolved to target tags across the task.
 func (s *knowledgeService) moveOneKnowledge(
 	ctx context.Context,
 	knowledgeID string,
 	sourceKB, targetKB *types.KnowledgeBase,
 	mode string,
+	tagIDMapping map[string]string,
 ) error {
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 
@@ -990,6 +1055,14 @@ func (s *knowledgeService) moveOneKnowledge(
 		return fmt.Errorf("failed to get knowledge %s: %w", knowledgeID, err)
 	}
 
+	// Moved by an earlier attempt of this task
+	if knowledge.KnowledgeBaseID == targetKB.ID {
+		return nil
+	}
+	if knowledge.KnowledgeBaseID != sourceKB.ID {
+		return fmt.Errorf("knowledge %s does not belong to source knowledge base %s", knowledgeID, sourceKB.ID)
+	}
+
 	// Only move completed items
 	if knowledge.ParseStatus != types.ParseStatusCompleted {
 		return fmt.Errorf("knowledge %s is not in completed status (current: %s)", knowledgeID, knowledge.ParseStatus)
@@ -1015,7 +1088,7 @@ func (s *knowledgeService) moveOneKnowledge(
 
 	switch mode {
 	case "reuse_vectors":
-		return s.moveKnowledgeReuseVectors(ctx, knowledge, sourceKB, targetKB)
+		return s.moveKnowledgeReuseVectors(ctx, knowledge, sourceKB, targetKB, tagIDMapping)
 	case "reparse":
 		return s.moveKnowledgeReparse(ctx, knowledge, sourceKB, targetKB)
 	default:
@@ -1023,92 +1096,156 @@ func (s *knowledgeService) moveOneKnowledge(
 	}
 }
 
-// moveKnowledgeReuseVectors moves knowledge by copying vector indices and updating DB references.
+// moveKnowledgeReuseVectors moves knowledge together with its vector indices.
+// Tags are mapped to same-name tags of the target (created when missing).
+// Engines that can reassign rows in place (interfaces.IndexMover) move the
+// indices first and the database rows in one transaction after; a failed
+// transaction moves the indices back. Other engines get the moved chunks
+// re-indexed into the target.
 func (s *knowledgeService) moveKnowledgeReuseVectors(
 	ctx context.Context,
 	knowledge *types.Knowledge,
 	sourceKB, targetKB *types.KnowledgeBase,
+	tagIDMapping map[string]string,
 ) error {
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
 
-	// reuse_vectors copies index entries directly between KBs, which only works
-	// inside the same VectorStore backend (CopyIndices is routed through the
-	// source store). A cross-store reuse_vectors move would write target-KB rows
-	// into the source store and then delete the source indices, corrupting data.
-	// The MoveKnowledge handler rejects this up front; this is defense-in-depth
-	// for any path that enqueues a move task directly. Cross-store moves must use
-	// reparse mode (moveKnowledgeReparse), which re-indexes into the target store.
+	// Rewriting index rows in place only works inside the same VectorStore
+	// backend. The MoveKnowledge handler rejects cross-store reuse_vectors
+	// moves up front; this is defense-in-depth for any path that enqueues a
+	// move task directly. Cross-store moves must use reparse mode.
 	if !sourceKB.SharesStoreWith(targetKB) {
 		return fmt.Errorf(
 			"reuse_vectors move across different vector stores is not supported "+
 				"(source KB %s, target KB %s); use reparse mode", sourceKB.ID, targetKB.ID)
 	}
 
-	// 1. Get old chunk IDs for vector index copy mapping
-	oldChunks, err := s.chunkRepo.ListChunksByKnowledgeID(ctx, tenantID, knowledge.ID)
+	chunks, err := s.chunkRepo.ListChunksByKnowledgeID(ctx, tenantID, knowledge.ID)
 	if err != nil {
 		return fmt.Errorf("failed to list chunks: %w", err)
 	}
-
-	// Build identity mapping (same chunk IDs, just moving between KBs)
-	chunkIDMapping := make(map[string]string, len(oldChunks))
-	for _, c := range oldChunks {
-		chunkIDMapping[c.ID] = c.ID
+	knowledgeTags, err := s.repo.GetKnowledgeTags(ctx, []string{knowledge.ID})
+	if err != nil {
+		return fmt.Errorf("failed to get knowledge tags: %w", err)
 	}
 
-	// 2. Copy vector indices from source KB to target KB
-	if len(chunkIDMapping) > 0 && knowledge.EmbeddingModelID != "" {
-		// Same VectorStore backend is guaranteed by the SharesStoreWith guard at
-		// the top of this function, so routing CopyIndices through the source
-		// KB's binding also resolves the target's store.
-		var sourceStoreID *string
-		if sourceKB != nil {
-			sourceStoreID = sourceKB.VectorStoreID
+	// Resolve every tag the knowledge or its chunks use in the target KB
+	mapTag := func(srcTagID string) string {
+		if srcTagID == "" {
+			return ""
 		}
-		retrieveEngine, err := retriever.CreateRetrieveEngineForKB(
-			ctx, s.retrieveEngine, s.ownership, tenantID, sourceStoreID)
-		if err != nil {
-			return fmt.Errorf("failed to init retrieve engine: %w", err)
+		if dstTagID, ok := tagIDMapping[srcTagID]; ok {
+			return dstTagID
+		}
+		return s.getOrCreateTagInTarget(ctx, tenantID, tenantID, targetKB.ID, srcTagID, tagIDMapping)
+	}
+	tagMapping := make(map[string]string)
+	for _, tag := range knowledgeTags[knowledge.ID] {
+		tagMapping[tag.ID] = mapTag(tag.ID)
+	}
+	chunkTagMap := make(map[string]string)
+	chunkTagRevert := make(map[string]string)
+	for _, c := range chunks {
+		if c.TagID == "" {
+			continue
 		}
-		embeddingModel, err := s.modelService.GetEmbeddingModel(ctx, knowledge.EmbeddingModelID)
+		tagMapping[c.TagID] = mapTag(c.TagID)
+		chunkTagMap[c.ID] = tagMapping[c.TagID]
+		chunkTagRevert[c.ID] = c.TagID
+	}
+
+	var (
+		retrieveEngine *retriever.CompositeRetrieveEngine
+		reindex        bool
+	)
+	if len(chunks) > 0 && knowledge.EmbeddingModelID != "" {
+		// Same VectorStore backend is guaranteed by the SharesStoreWith guard
+		// above, so the source KB's binding also resolves the target's store.
+		retrieveEngine, err = retriever.CreateRetrieveEngineForKB(
+			ctx, s.retrieveEngine, s.ownership, tenantID, sourceKB.VectorStoreID)
 		if err != nil {
-			return fmt.Errorf("failed to get embedding model: %w", err)
+			return fmt.Errorf("failed to init retrieve engine: %w", err)
 		}
-
-		// Copy indices from source KB to target KB
-		knowledgeIDMapping := map[string]string{knowledge.ID: knowledge.ID}
-		if err := retrieveEngine.CopyIndices(ctx, sourceKB.ID, targetKB.ID,
-			knowledgeIDMapping, chunkIDMapping,
-			embeddingModel.GetDimensions(), sourceKB.Type,
-		); err != nil {
-			return fmt.Errorf("failed to copy indices: %w", err)
+		err = retrieveEngine.MoveIndices(ctx, []string{knowledge.ID}, targetKB.ID, chunkTagMap)
+		switch {
+		case errors.Is(err, retriever.ErrMoveUnsupported):
+			reindex = true
+		case err != nil:
+			return fmt.Errorf("failed to move indices: %w", err)
 		}
+	}
 
-		// Delete indices from source KB
-		if err := retrieveEngine.DeleteByKnowledgeIDList(ctx, []string{knowledge.ID},
-			embeddingModel.GetDimensions(), sourceKB.Type,
-		); err != nil {
-			logger.Warnf(ctx, "moveKnowledgeReuseVectors: failed to delete old indices for knowledge %s: %v", knowledge.ID, err)
-			// Non-fatal: indices will be orphaned but won't affect correctness
+	if err := s.repo.MoveKnowledgeToKnowledgeBase(ctx, tenantID, knowledge.ID, targetKB.ID, tagMapping); err != nil {
+		if retrieveEngine != nil && !reindex {
+			if rerr := retrieveEngine.MoveIndices(ctx, []string{knowledge.ID}, sourceKB.ID, chunkTagRevert); rerr != nil {
+				logger.Errorf(ctx, "moveKnowledgeReuseVectors: failed to move indices of %s back: %v", knowledge.ID, rerr)
+			}
 		}
+		return fmt.Errorf("failed to move knowledge: %w", err)
 	}
-
-	// 3. Update chunks' knowledge_base_id in DB
-	if err := s.chunkRepo.MoveChunksByKnowledgeID(ctx, tenantID, knowledge.ID, targetKB.ID); err != nil {
-		return fmt.Errorf("failed to move chunks: %w", err)
+	if !reindex {
+		return nil
 	}
 
-	// 4. Update knowledge record (tags are KB-scoped; clear relations before moving)
-	if err := s.repo.DeleteKnowledgeTagRelations(ctx, knowledge.ID); err != nil {
-		return fmt.Errorf("failed to clear knowledge tag relations: %w", err)
+	for _, c := range chunks {
+		c.KnowledgeBaseID = targetKB.ID
+		c.TagID = tagMapping[c.TagID]
 	}
 	knowledge.KnowledgeBaseID = targetKB.ID
-	knowledge.ParseStatus = types.ParseStatusCompleted
-	knowledge.UpdatedAt = time.Now()
-	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
-		return fmt.Errorf("failed to update knowledge: %w", err)
+	if err := s.reindexMovedKnowledge(ctx, retrieveEngine, knowledge, targetKB, chunks); err != nil {
+		// The rows already live in the target; leave the entry failed so a
+		// reparse rebuilds its index.
+		_ = s.repo.UpdateKnowledgeColumns(ctx, knowledge.ID, map[string]interface{}{
+			"parse_status":  types.ParseStatusFailed,
+			"error_message": "Failed to index moved knowledge: " + err.Error(),
+		})
+		return err
 	}
+	return nil
+}
 
+// reindexMovedKnowledge replaces the index rows of a moved knowledge entry
+// with rows built from its chunks for engines that cannot move rows in place.
+func (s *knowledgeService) reindexMovedKnowledge(
+	ctx context.Context,
+	engine *retriever.CompositeRetrieveEngine,
+	knowledge *types.Knowledge,
+	targetKB *types.KnowledgeBase,
+	chunks []*types.Chunk,
+) error {
+	embeddingModel, err := s.modelService.GetEmbeddingModel(ctx, knowledge.EmbeddingModelID)
+	if err != nil {
+		return fmt.Errorf("failed to get embedding model: %w", err)
+	}
+	var infos []*types.IndexInfo
+	if targetKB.Type == types.KnowledgeBaseTypeFAQ {
+		for _, c := range chunks {
+			list, err := s.buildFAQIndexInfoList(ctx, targetKB, c)
+			if err != nil {
+				return fmt.Errorf("failed to build index of FAQ entry %s: %w", c.ID, err)
+			}
+			infos = append(infos, list...)
+		}
+	} else {
+		tags := make(map[string]string, len(chunks))
+		for _, c := range chunks {
+			tags[c.ID] = c.TagID
+		}
+		infos = generationIndexInfo(knowledge, chunks)
+		for _, info := range infos {
+			info.TagID = tags[info.ChunkID]
+		}
+	}
+	if err := engine.DeleteByKnowledgeIDList(ctx, []string{knowledge.ID},
+		embeddingModel.GetDimensions(), knowledge.Type); err != nil {
+		return fmt.Errorf("failed to delete old indices: %w", err)
+	}
+	if len(infos) == 0 {
+		return nil
+	}
+	if err := engine.BatchIndex(ctx, embeddingModel, infos); err != nil {
+		return fmt.Errorf("failed to index moved chunks: %w", err)
+	}
 	return nil
 }
 
@@ -1119,6 +1256,7 @@ func (s *knowledgeService) moveKnowledgeReparse(
 	_, targetKB *types.KnowledgeBase,
 ) error {
 	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	ctx = types.WithIngestPriority(ctx, types.IngestPriorityBulk)
 
 	// 1. Clean up existing chunks and vector indices
 	if err := s.cleanupKnowledgeResources(ctx, knowledge); err != nil {
@@ -1183,7 +1321,7 @@ func (s *knowledgeService) moveKnowledgeReparse(
 		}
 
 		task := asynq.NewTask(types.TypeDocumentProcess, payloadBytes,
-			documentProcessTaskOptions(s.config, asynq.MaxRetry(3))...)
+			documentProcessTaskOptions(ctx, s.config, asynq.MaxRetry(3))...)
 		info, err := s.task.Enqueue(task)
 		if err != nil {
 			return fmt.Errorf("failed to enqueue document process task: %w", err)
@@ -1220,6 +1358,18 @@ func (s *knowledgeService) getOrCreateTagInTarget(
 		return dstTag.ID
 	}
 
+	// Create the parent chain first so the copy keeps its place in the hierarchy.
+	// The placeholder stops a corrupted parent cycle from recursing forever.
+	parentID := ""
+	if srcTag.ParentID != "" {
+		tagIDMapping[srcTagID] = ""
+		if mapped, ok := tagIDMapping[srcTag.ParentID]; ok {
+			parentID = mapped
+		} else {
+			parentID = s.getOrCreateTagInTarget(ctx, srcTenantID, dstTenantID, dstKnowledgeBaseID, srcTag.ParentID, tagIDMapping)
+		}
+	}
+
 	// Create new tag in target KB
 	// "未分类" tag should have the lowest sort order to appear first
 	sortOrder := srcTag.SortOrder
@@ -1230,6 +1380,7 @@ func (s *knowledgeService) getOrCreateTagInTarget(
 		ID:              uuid.New().String(),
 		TenantID:        dstTenantID,
 		KnowledgeBaseID: dstKnowledgeBaseID,
+		ParentID:        parentID,
 		Name:            srcTag.Name,
 		Color:           srcTag.Color,
 		SortOrder:       sortOrder,
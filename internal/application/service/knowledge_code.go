@@ -0,0 +1,166 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path"
+	"strings"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/infrastructure/chunker"
+	"github.com/Tencent/WeKnora/internal/infrastructure/docparser"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// maxCodeArchiveFiles caps the source files imported from one archive.
+	maxCodeArchiveFiles = 5000
+	// maxCodeFileSize skips source files larger than this; they are almost
+	// always generated or minified.
+	maxCodeFileSize = 1 << 20
+)
+
+// splitCodeChunks splits a source file by symbol. The chunks carry the
+// file path and symbol as metadata and are never parent-child chunks.
+func splitCodeChunks(text, filePath string, cfg chunker.SplitterConfig) []types.ParsedChunk {
+	splitChunks := chunker.SplitCode(text, filePath, cfg)
+	chunks := make([]types.ParsedChunk, len(splitChunks))
+	for i, c := range splitChunks {
+		chunks[i] = types.ParsedChunk{
+			Content:       c.Content,
+			ContextHeader: c.ContextHeader,
+			Seq:           c.Seq,
+			Start:         c.Start,
+			End:           c.End,
+			Metadata:      splitChunkMetadata(c),
+		}
+	}
+	return chunks
+}
+
+// codeFilePath returns the repository path recorded on a chunk of a source
+// file, or "" when c was not cut by the code splitter.
+func codeFilePath(c *types.Chunk) string {
+	if c == nil {
+		return ""
+	}
+	meta, err := c.DocumentMetadata()
+	if err != nil || meta == nil || meta.Code == nil {
+		return ""
+	}
+	return meta.Code.FilePath
+}
+
+// codeArchiveEntryPath returns the repository path under which a zip entry
+// is imported, or "" when the entry is skipped: directories, oversized
+// files and files that are not ingested from repositories (see
+// docparser.IsRepositoryFile). prefix is the top-level directory shared by
+// all entries (as in GitHub archives), stripped from the path.
+func codeArchiveEntryPath(f *zip.File, prefix string) string {
+	if f.FileInfo().IsDir() || f.UncompressedSize64 > maxCodeFileSize {
+		return ""
+	}
+	name := strings.TrimPrefix(strings.ReplaceAll(f.Name, "\\", "/"), prefix)
+	if !docparser.IsRepositoryFile(name) {
+		return ""
+	}
+	return name
+}
+
+// codeArchivePrefix returns the top-level directory shared by every entry of
+// the archive, with a trailing slash, or "" when there is none.
+func codeArchivePrefix(files []*zip.File) string {
+	prefix := ""
+	for i, f := range files {
+		name := strings.ReplaceAll(f.Name, "\\", "/")
+		dir, _, found := strings.Cut(name, "/")
+		if !found {
+			return ""
+		}
+		if i == 0 {
+			prefix = dir + "/"
+		} else if dir+"/" != prefix {
+			return ""
+		}
+	}
+	return prefix
+}
+
+// CreateKnowledgeFromCodeArchive imports the source files and Markdown
+// documents of a zip archive of a repository, one knowledge per file titled
+// with its repository path.
+// Files that fail to import are reported in the result and do not stop the
+// import.
+func (s *knowledgeService) CreateKnowledgeFromCodeArchive(ctx context.Context,
+	kbID string, file *multipart.FileHeader, tagIDs []string, channel string,
+) (*types.CodeArchiveImportResult, error) {
+	if !strings.EqualFold(path.Ext(file.Filename), ".zip") {
+		return nil, werrors.NewBadRequestError("仅支持 zip 格式的代码压缩包")
+	}
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := zip.NewReader(f, file.Size)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to open code archive %s: %v", file.Filename, err)
+		return nil, werrors.NewBadRequestError("无法解析代码压缩包")
+	}
+
+	// An archive can hold thousands of files: parse them in the bulk lane so
+	// other users' uploads are not queued behind it.
+	ctx = types.WithIngestPriority(ctx, types.IngestPriorityBulk)
+	result := &types.CodeArchiveImportResult{}
+	prefix := codeArchivePrefix(zr.File)
+	for _, entry := range zr.File {
+		filePath := codeArchiveEntryPath(entry, prefix)
+		if filePath == "" {
+			if !entry.FileInfo().IsDir() {
+				result.Skipped++
+			}
+			continue
+		}
+		if len(result.Imported) >= maxCodeArchiveFiles {
+			result.Skipped++
+			continue
+		}
+		knowledge, err := s.importCodeArchiveEntry(ctx, kbID, entry, filePath, tagIDs, channel)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to import %s from code archive %s: %v", filePath, file.Filename, err)
+			result.Failed = append(result.Failed, types.CodeArchiveFailure{FilePath: filePath, Error: err.Error()})
+			continue
+		}
+		result.Imported = append(result.Imported, knowledge)
+	}
+	logger.Infof(ctx, "Imported %d source files from code archive %s (%d skipped, %d failed)",
+		len(result.Imported), file.Filename, result.Skipped, len(result.Failed))
+	return result, nil
+}
+
+func (s *knowledgeService) importCodeArchiveEntry(ctx context.Context,
+	kbID string, entry *zip.File, filePath string, tagIDs []string, channel string,
+) (*types.Knowledge, error) {
+	rc, err := entry.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, maxCodeFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCodeFileSize {
+		return nil, fmt.Errorf("file larger than %d bytes", maxCodeFileSize)
+	}
+	fh, err := bytesToFileHeader(data, path.Base(filePath))
+	if err != nil {
+		return nil, err
+	}
+	metadata := map[string]string{"file_path": filePath}
+	return s.CreateKnowledgeFromFile(ctx, kbID, fh, metadata, nil, filePath, tagIDs, channel, nil)
+}
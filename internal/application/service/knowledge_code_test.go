@@ -0,0 +1,87 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/infrastructure/chunker"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCodeChunks_Metadata(t *testing.T) {
+	text := "package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"
+	chunks := splitCodeChunks(text, "cmd/app/main.go", chunker.SplitterConfig{ChunkSize: 500})
+	require.Len(t, chunks, 2)
+
+	code := chunks[1].Metadata.Code
+	require.NotNil(t, code)
+	assert.Equal(t, "cmd/app/main.go", code.FilePath)
+	assert.Equal(t, "go", code.Language)
+	assert.Equal(t, "main", code.Symbol)
+	assert.Equal(t, "function", code.SymbolKind)
+	assert.Equal(t, 3, code.StartLine)
+	assert.Equal(t, 5, code.EndLine)
+	assert.Equal(t, "cmd/app/main.go > main", chunks[1].ContextHeader)
+
+	chunk := &types.Chunk{ID: "c1"}
+	assert.Equal(t, "", codeFilePath(chunk))
+	require.NoError(t, chunk.SetDocumentMetadata(chunks[1].Metadata))
+	assert.Equal(t, "cmd/app/main.go", codeFilePath(chunk))
+}
+
+func TestRechunkToGeneration_SourceFile(t *testing.T) {
+	text := "package main\n\nfunc a() {}\n\nfunc b() {}\n"
+	parsed := splitCodeChunks(text, "main.go", chunker.SplitterConfig{ChunkSize: 500})
+	var source []*types.Chunk
+	for _, p := range parsed {
+		c := &types.Chunk{ID: "old", ChunkType: types.ChunkTypeText, Content: p.Content, StartAt: p.Start, EndAt: p.End}
+		require.NoError(t, c.SetDocumentMetadata(p.Metadata))
+		source = append(source, c)
+	}
+
+	// Parent-child chunking is ignored for source files.
+	rebuilt := rechunkToGeneration(source, types.ChunkingConfig{ChunkSize: 500, EnableParentChild: true}, 2)
+	require.Len(t, rebuilt, 3)
+	for _, c := range rebuilt {
+		assert.Equal(t, types.ChunkTypeText, c.ChunkType)
+		assert.Equal(t, "main.go", codeFilePath(c))
+	}
+	meta, err := rebuilt[2].DocumentMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, "b", meta.Code.Symbol)
+}
+
+func TestCodeArchiveEntryPath(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range []string{
+		"repo-main/",
+		"repo-main/cmd/main.go",
+		"repo-main/README.md",
+		"repo-main/LICENSE",
+		"repo-main/.github/workflows/ci.go",
+		"repo-main/vendor/lib/lib.go",
+		"repo-main/web/node_modules/x/index.js",
+		"repo-main/web/src/App.tsx",
+		"repo-main/../evil.go",
+	} {
+		_, err := zw.Create(name)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	prefix := codeArchivePrefix(zr.File)
+	assert.Equal(t, "repo-main/", prefix)
+	var paths []string
+	for _, f := range zr.File {
+		if p := codeArchiveEntryPath(f, prefix); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	assert.Equal(t, []string{"cmd/main.go", "README.md", "web/src/App.tsx"}, paths)
+}
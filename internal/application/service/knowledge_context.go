@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// contextualBatchSize is the number of chunks situated by one model
+	// call: the document text is sent once per batch, not once per chunk.
+	contextualBatchSize = 8
+	// contextualConcurrency bounds the model calls in flight per document.
+	contextualConcurrency = 4
+	// maxContextualChunks caps the chunks of one document given a preamble.
+	maxContextualChunks = 1000
+	// maxContextualDocumentRunes bounds the document text sent with a
+	// batch; longer documents are cut to a window around the batch.
+	maxContextualDocumentRunes = 12000
+	// maxContextualChunkRunes truncates each chunk in the prompt.
+	maxContextualChunkRunes = 2000
+	// maxContextPreambleRunes truncates an overlong preamble.
+	maxContextPreambleRunes = 400
+
+	// contextualPromptTemplate is the prompt template for situating a batch
+	// of chunks within their document (contextual retrieval).
+	contextualPromptTemplate = `<document title="%s">
+%s
+</document>
+
+Here are %d chunks taken from the document above:
+
+%s
+
+For each chunk, write a short context (1-2 sentences) that situates the chunk within the overall document, to improve search retrieval of the chunk. Name the subject the chunk is about when the chunk itself does not.
+Answer with exactly one line per chunk in the form "[n] context", in the same language as the document, and nothing else.`
+)
+
+// contextPreambleLine matches a "[n] context" line of the model's answer.
+var contextPreambleLine = regexp.MustCompile(`(?m)^\s*\[(\d+)\]\s*(.+?)\s*$`)
+
+// contextualizeChunks asks the knowledge base's summary model for a short
+// preamble situating each chunk within document and stores it in the chunk
+// metadata, from where processChunks embeds it together with the chunk.
+// Chunks are sent in batches that share the document text. Failures are
+// logged and leave the affected chunks without a preamble.
+func (s *knowledgeService) contextualizeChunks(ctx context.Context,
+	kb *types.KnowledgeBase, knowledge *types.Knowledge, document string, chunks []types.ParsedChunk,
+) {
+	if kb.SummaryModelID == "" {
+		logger.Warnf(ctx, "Contextual retrieval enabled but knowledge base %s has no summary model", kb.ID)
+		return
+	}
+	var targets []int
+	for i, c := range chunks {
+		if strings.TrimSpace(c.Content) != "" {
+			targets = append(targets, i)
+		}
+	}
+	if len(targets) == 0 || strings.TrimSpace(document) == "" {
+		return
+	}
+	if len(targets) > maxContextualChunks {
+		logger.Infof(ctx, "Contextualizing the first %d of %d chunks of knowledge %s",
+			maxContextualChunks, len(targets), knowledge.ID)
+		targets = targets[:maxContextualChunks]
+	}
+
+	chatModel, err := s.modelService.GetChatModel(ctx, kb.SummaryModelID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get summary model for contextual retrieval: %v", err)
+		return
+	}
+
+	docRunes := []rune(document)
+	preambles := make([]string, len(chunks))
+	var failedBatches atomic.Int32
+	var g errgroup.Group
+	g.SetLimit(contextualConcurrency)
+	for start := 0; start < len(targets); start += contextualBatchSize {
+		batch := targets[start:min(start+contextualBatchSize, len(targets))]
+		g.Go(func() error {
+			batchChunks := make([]types.ParsedChunk, len(batch))
+			for j, i := range batch {
+				batchChunks[j] = chunks[i]
+			}
+			excerpt := documentExcerpt(docRunes, batchChunks)
+			got, err := situateChunks(ctx, chatModel, knowledge.Title, excerpt, batchChunks)
+			if err != nil {
+				failedBatches.Add(1)
+				logger.Warnf(ctx, "Failed to contextualize chunks %d-%d of knowledge %s: %v",
+					chunks[batch[0]].Seq, chunks[batch[len(batch)-1]].Seq, knowledge.ID, err)
+				return nil
+			}
+			for j, i := range batch {
+				preambles[i] = got[j]
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	contextualized := 0
+	for i, p := range preambles {
+		if p == "" {
+			continue
+		}
+		if chunks[i].Metadata == nil {
+			chunks[i].Metadata = &types.DocumentChunkMetadata{}
+		}
+		chunks[i].Metadata.ContextPreamble = p
+		contextualized++
+	}
+	logger.Infof(ctx, "Contextualized %d/%d chunks of knowledge %s in %d calls (%d failed)",
+		contextualized, len(targets), knowledge.ID,
+		(len(targets)+contextualBatchSize-1)/contextualBatchSize, failedBatches.Load())
+}
+
+// situateChunks asks the model for the preambles of one batch of chunks.
+// The result has one entry per chunk; chunks the model skipped get "".
+func situateChunks(ctx context.Context, chatModel chat.Chat,
+	docName, excerpt string, batch []types.ParsedChunk,
+) ([]string, error) {
+	var b strings.Builder
+	for j, c := range batch {
+		if j > 0 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "<chunk id=\"%d\">\n%s\n</chunk>", j+1, truncateRunes(strings.TrimSpace(c.Content), maxContextualChunkRunes))
+	}
+	thinking := false
+	response, err := chatModel.Chat(ctx, []chat.Message{{
+		Role:    "user",
+		Content: fmt.Sprintf(contextualPromptTemplate, docName, excerpt, len(batch), b.String()),
+	}}, &chat.ChatOptions{
+		Temperature: 0.2,
+		MaxTokens:   120 * len(batch),
+		Thinking:    &thinking,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseContextPreambles(response.Content, len(batch)), nil
+}
+
+// parseContextPreambles extracts the "[n] context" lines of an answer for n
+// chunks. Lines with an unknown number are ignored.
+func parseContextPreambles(answer string, n int) []string {
+	out := make([]string, n)
+	for _, m := range contextPreambleLine.FindAllStringSubmatch(answer, -1) {
+		k, err := strconv.Atoi(m[1])
+		if err != nil || k < 1 || k > n || out[k-1] != "" {
+			continue
+		}
+		out[k-1] = truncateRunes(m[2], maxContextPreambleRunes)
+	}
+	return out
+}
+
+// documentExcerpt returns the document text sent with a batch: the whole
+// document when it is short enough, otherwise a window of
+// maxContextualDocumentRunes centred on the chunks of the batch.
+func documentExcerpt(doc []rune, batch []types.ParsedChunk) string {
+	if len(doc) <= maxContextualDocumentRunes {
+		return string(doc)
+	}
+	first, last := batch[0], batch[len(batch)-1]
+	centre := 0
+	if first.Start >= 0 && last.End > first.Start && last.End <= len(doc) {
+		centre = (first.Start + last.End) / 2
+	}
+	lo := max(0, centre-maxContextualDocumentRunes/2)
+	hi := min(len(doc), lo+maxContextualDocumentRunes)
+	lo = max(0, hi-maxContextualDocumentRunes)
+	excerpt := string(doc[lo:hi])
+	if lo > 0 {
+		excerpt = "...\n" + excerpt
+	}
+	if hi < len(doc) {
+		excerpt += "\n..."
+	}
+	return excerpt
+}
+
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n]) + "..."
+	}
+	return s
+}
+
+// chunkIndexContent returns the text indexed for a text chunk: the title
+// prefix, the chunk's context preamble when it has one, then the chunk with
+// its heading breadcrumb (Chunk.EmbeddingContent).
+func chunkIndexContent(titlePrefix string, c *types.Chunk) string {
+	content := c.EmbeddingContent()
+	if meta, err := c.DocumentMetadata(); err == nil && meta != nil && meta.ContextPreamble != "" {
+		content = meta.ContextPreamble + "\n\n" + content
+	}
+	return titlePrefix + content
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseContextPreambles(t *testing.T) {
+	answer := "[1] 本段介绍 2023 年第二季度的营收。\n\n[3]  该段说明退款政策。 \n[9] 多余的行\n[1] 重复的行"
+	got := parseContextPreambles(answer, 3)
+	assert.Equal(t, []string{"本段介绍 2023 年第二季度的营收。", "", "该段说明退款政策。"}, got)
+}
+
+func TestSituateChunks(t *testing.T) {
+	model := &templateCaptureChatModel{response: "[1] Part of the ACME 2023 annual report, on Q2 revenue.\n[2] Describes ACME's refund policy."}
+	batch := []types.ParsedChunk{{Content: "Revenue grew 3%."}, {Content: "Refunds within 30 days."}}
+
+	got, err := situateChunks(context.Background(), model, "ACME 2023", "full document", batch)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Part of the ACME 2023 annual report, on Q2 revenue.", "Describes ACME's refund policy."}, got)
+	assert.Contains(t, model.prompt, `<document title="ACME 2023">`)
+	assert.Contains(t, model.prompt, "<chunk id=\"2\">\nRefunds within 30 days.\n</chunk>")
+}
+
+func TestDocumentExcerpt(t *testing.T) {
+	short := []rune("short document")
+	assert.Equal(t, "short document", documentExcerpt(short, []types.ParsedChunk{{Start: 0, End: 5}}))
+
+	long := []rune(strings.Repeat("a", maxContextualDocumentRunes) + strings.Repeat("b", maxContextualDocumentRunes))
+	head := documentExcerpt(long, []types.ParsedChunk{{Start: 0, End: 10}})
+	assert.True(t, strings.HasPrefix(head, "aaa"))
+	assert.True(t, strings.HasSuffix(head, "\n..."))
+	assert.Len(t, []rune(strings.TrimSuffix(head, "\n...")), maxContextualDocumentRunes)
+
+	tail := documentExcerpt(long, []types.ParsedChunk{{Start: len(long) - 10, End: len(long)}})
+	assert.True(t, strings.HasPrefix(tail, "...\nbbb"))
+	assert.False(t, strings.Contains(tail, "a"))
+}
+
+func TestChunkIndexContent(t *testing.T) {
+	chunk := &types.Chunk{Content: "Revenue grew 3%.", ContextHeader: "Results"}
+	assert.Equal(t, "Report\nResults\n\nRevenue grew 3%.", chunkIndexContent("Report\n", chunk))
+
+	require.NoError(t, chunk.SetDocumentMetadata(&types.DocumentChunkMetadata{ContextPreamble: "ACME Q2 2023 revenue."}))
+	assert.Equal(t, "Report\nACME Q2 2023 revenue.\n\nResults\n\nRevenue grew 3%.", chunkIndexContent("Report\n", chunk))
+}
@@ -39,7 +39,7 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 
 	logger.Infof(ctx, "Knowledge base ID: %s, file: %s", kbID, fileName)
 
-	if IsVideoType(getFileType(fileName)) {
+	if IsVideoType(getFileType(fileName)) && !IsTranscribableType(getFileType(fileName)) {
 		logger.Error(ctx, "Video file upload is not supported")
 		return nil, werrors.NewBadRequestError("暂不支持上传视频文件")
 	}
@@ -109,6 +109,8 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 	// Convert metadata to JSON format if provided
 	var metadataJSON types.JSON
 	if metadata != nil {
+		// The ACL key is reserved; ACLs are set through UpdateKnowledgeACL
+		delete(metadata, types.KnowledgeACLMetadataKey)
 		metadataBytes, err := json.Marshal(metadata)
 		if err != nil {
 			logger.Errorf(ctx, "Failed to marshal metadata: %v", err)
@@ -174,14 +176,20 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 			}
 		}
 
-		if IsAudioType(getFileType(safeFilename)) {
+		if IsTranscribableType(getFileType(safeFilename)) {
 			if !kb.ASRConfig.IsASREnabled() {
 				logger.Error(ctx, "ASR model is not configured")
-				return nil, werrors.NewBadRequestError("上传音频文件需要设置ASR语音识别模型")
+				return nil, werrors.NewBadRequestError("上传音视频文件需要设置ASR语音识别模型")
 			}
 		}
 	}
 
+	// Scan the upload before anything is stored
+	scan, err := s.scanUpload(ctx, kbID, file, safeFilename)
+	if err != nil {
+		return nil, err
+	}
+
 	// Prepare knowledge record
 	logger.Info(ctx, "Preparing knowledge record")
 	knowledge := &types.Knowledge{
@@ -219,6 +227,11 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		return nil, err
 	}
 	knowledge.FilePath = filePath
+	knowledge.EncryptionKeyID = fileEncryptionKeyID(fileSvc)
+	if scan != nil && scan.quarantined {
+		knowledge.ParseStatus = types.ParseStatusQuarantined
+		knowledge.ErrorMessage = "文件未通过安全扫描，已隔离: " + scan.signature
+	}
 
 	// Save knowledge record to database after the file is safely stored.
 	logger.Info(ctx, "Saving knowledge record to database")
@@ -229,6 +242,7 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		}
 		return nil, err
 	}
+	s.recordUploadScan(ctx, scan, knowledge.ID)
 
 	// Set tag relations
 	if err := s.setAndAttachKnowledgeTags(ctx, tenantID, kbID, knowledge, tagIDs); err != nil {
@@ -236,6 +250,15 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 		return nil, err
 	}
 
+	// Quarantined uploads are kept for review and never reach ingestion
+	if knowledge.ParseStatus == types.ParseStatusQuarantined {
+		logger.Warnf(ctx, "Knowledge %s quarantined, skipping document processing", knowledge.ID)
+		return knowledge, nil
+	}
+
+	// Thumbnails for the knowledge list are rendered in the background
+	s.enqueueFilePreview(ctx, knowledge)
+
 	// Enqueue document processing task to Asynq
 	logger.Info(ctx, "Enqueuing document processing task to Asynq")
 	enableMultimodelValue := eff.EnableMultimodel
@@ -271,7 +294,7 @@ func (s *knowledgeService) CreateKnowledgeFromFile(ctx context.Context,
 	task := asynq.NewTask(
 		types.TypeDocumentProcess,
 		payloadBytes,
-		documentProcessTaskOptions(s.config, asynq.MaxRetry(3))...,
+		documentProcessTaskOptions(ctx, s.config, asynq.MaxRetry(3))...,
 	)
 	info, err := s.task.Enqueue(task)
 	if err != nil {
@@ -452,7 +475,7 @@ func (s *knowledgeService) CreateKnowledgeFromURL(ctx context.Context,
 	task := asynq.NewTask(
 		types.TypeDocumentProcess,
 		payloadBytes,
-		documentProcessTaskOptions(s.config, asynq.MaxRetry(3))...,
+		documentProcessTaskOptions(ctx, s.config, asynq.MaxRetry(3))...,
 	)
 	info, err := s.task.Enqueue(task)
 	if err != nil {
@@ -477,6 +500,8 @@ var allowedFileURLExtensions = map[string]bool{
 	"m4a":  true,
 	"flac": true,
 	"ogg":  true,
+	"mp4":  true,
+	"webm": true,
 }
 
 // maxFileURLSize is the maximum allowed file size for file URL import (10MB)
@@ -691,7 +716,7 @@ func (s *knowledgeService) createKnowledgeFromFileURL(
 	task := asynq.NewTask(
 		types.TypeDocumentProcess,
 		payloadBytes,
-		documentProcessTaskOptions(s.config)...,
+		documentProcessTaskOptions(ctx, s.config)...,
 	)
 	info, err := s.task.Enqueue(task)
 	if err != nil {
@@ -926,7 +951,7 @@ func (s *knowledgeService) createKnowledgeFromPassageInternal(ctx context.Contex
 		task := asynq.NewTask(
 			types.TypeDocumentProcess,
 			payloadBytes,
-			documentProcessTaskOptions(s.config, asynq.MaxRetry(3))...,
+			documentProcessTaskOptions(ctx, s.config, asynq.MaxRetry(3))...,
 		)
 		info, err := s.task.Enqueue(task)
 		if err != nil {
@@ -1168,6 +1193,7 @@ func (s *knowledgeService) triggerManualProcessing(ctx context.Context,
 				Start:         c.Start,
 				End:           c.End,
 				ParentIndex:   c.ParentIndex,
+				Metadata:      splitChunkMetadata(c.Chunk),
 			}
 		}
 		parentChunks := make([]types.ParsedParentChunk, len(pcResult.Parents))
@@ -1176,24 +1202,20 @@ func (s *knowledgeService) triggerManualProcessing(ctx context.Context,
 		}
 		opts.ParentChunks = parentChunks
 	} else {
-		splitChunks := chunker.Split(clean, chunkCfg)
-		parsed = make([]types.ParsedChunk, len(splitChunks))
-		for i, c := range splitChunks {
-			parsed[i] = types.ParsedChunk{
-				Content:       c.Content,
-				ContextHeader: c.ContextHeader,
-				Seq:           c.Seq,
-				Start:         c.Start,
-				End:           c.End,
-			}
-		}
+		parsed = splitFlatChunks(clean, eff.ChunkingConfig, chunkCfg)
 	}
 
-	if doSync {
+	process := func(ctx context.Context) {
+		if eff.ChunkingConfig.ContextualRetrieval {
+			s.contextualizeChunks(ctx, kb, knowledge, clean, parsed)
+		}
 		s.processChunks(ctx, kb, knowledge, parsed, opts)
+	}
+	if doSync {
+		process(ctx)
 		return
 	}
 
 	newCtx := logger.CloneContext(ctx)
-	go s.processChunks(newCtx, kb, knowledge, parsed, opts)
+	go process(newCtx)
 }
@@ -101,6 +101,10 @@ func (s *createKnowledgeFileServiceStub) GetFile(ctx context.Context, filePath s
 	return nil, errors.New("not implemented")
 }
 
+func (s *createKnowledgeFileServiceStub) GetFileRange(ctx context.Context, filePath string, offset, length int64) (io.ReadCloser, int64, error) {
+	return nil, 0, errors.New("not implemented")
+}
+
 func (s *createKnowledgeFileServiceStub) GetFileURL(ctx context.Context, filePath string) (string, error) {
 	return "", errors.New("not implemented")
 }
@@ -163,6 +163,7 @@ func (s *knowledgeService) DeleteKnowledge(ctx context.Context, id string) error
 				logger.GetLogger(ctx).WithField("error", err).Errorf("DeleteKnowledge delete file failed")
 			}
 		}
+		deleteKnowledgePreview(ctx, kbFileSvc, knowledge)
 		deleteExtractedImages(ctx, kbFileSvc, imageURLs)
 		tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
 		tenantInfo.StorageUsed -= knowledge.StorageSize
@@ -179,6 +180,9 @@ func (s *knowledgeService) DeleteKnowledge(ctx context.Context, id string) error
 			logger.GetLogger(ctx).WithField("error", err).Errorf("DeleteKnowledge delete knowledge graph failed")
 			return err
 		}
+		if types.GraphStoreEnabled() && knowledge.Type != types.KnowledgeTypeGraphCommunity {
+			ScheduleGraphCommunityBuild(ctx, s.task, knowledge.TenantID, knowledge.KnowledgeBaseID)
+		}
 		return nil
 	})
 
@@ -188,6 +192,11 @@ func (s *knowledgeService) DeleteKnowledge(ctx context.Context, id string) error
 	if err := s.repo.DeleteKnowledgeTagRelations(ctx, id); err != nil {
 		logger.Warnf(ctx, "Failed to delete tag relations for knowledge %s: %v", id, err)
 	}
+	if s.versionRepo != nil {
+		if err := s.versionRepo.DeleteByKnowledgeID(ctx, knowledge.TenantID, id); err != nil {
+			logger.Warnf(ctx, "Failed to delete versions of knowledge %s: %v", id, err)
+		}
+	}
 	// Delete the knowledge entry itself from the database
 	return s.repo.DeleteKnowledge(ctx, ctx.Value(types.TenantIDContextKey).(uint64), id)
 }
@@ -566,6 +575,7 @@ func (s *knowledgeService) DeleteKnowledgeList(ctx context.Context, ids []string
 				if err := fSvc.DeleteFile(ctx, knowledge.FilePath); err != nil {
 					logger.GetLogger(ctx).WithField("error", err).Errorf("DeleteKnowledge delete file failed")
 				}
+				deleteKnowledgePreview(ctx, fSvc, knowledge)
 			}
 			storageAdjust -= knowledge.StorageSize
 		}
@@ -598,6 +608,16 @@ func (s *knowledgeService) DeleteKnowledgeList(ctx context.Context, ids []string
 			logger.GetLogger(ctx).WithField("error", err).Errorf("DeleteKnowledge delete knowledge graph failed")
 			return err
 		}
+		if types.GraphStoreEnabled() {
+			scheduled := make(map[string]bool)
+			for _, knowledge := range knowledgeList {
+				if scheduled[knowledge.KnowledgeBaseID] || knowledge.Type == types.KnowledgeTypeGraphCommunity {
+					continue
+				}
+				scheduled[knowledge.KnowledgeBaseID] = true
+				ScheduleGraphCommunityBuild(ctx, s.task, knowledge.TenantID, knowledge.KnowledgeBaseID)
+			}
+		}
 		return nil
 	})
 
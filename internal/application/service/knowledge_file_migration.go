@@ -0,0 +1,231 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+
+	filesvc "github.com/Tencent/WeKnora/internal/application/service/file"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// fileMigrationConcurrency bounds the copies in flight while migrating a
+// knowledge base's files to another storage provider. Each copy holds the
+// whole file in memory.
+const fileMigrationConcurrency = 2
+
+// MigrateKnowledgeFiles moves every file of the knowledge base to
+// req.TargetProvider. Each file is read through the same file service that
+// serves its downloads, written to the target and read back to verify its
+// SHA-256 checksum. Only when every file made it are the stored paths
+// rewritten, in one transaction that also switches the knowledge base to the
+// target provider; otherwise the copies are removed and nothing changes, so a
+// failed run can be retried.
+func (s *knowledgeService) MigrateKnowledgeFiles(ctx context.Context,
+	kbID string, req *types.FileMigrationRequest,
+) (*types.FileMigrationResult, error) {
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+
+	target := strings.ToLower(strings.TrimSpace(req.TargetProvider))
+	dst, err := s.providerFileService(ctx, target)
+	if err != nil {
+		return nil, werrors.NewBadRequestError(err.Error())
+	}
+	keyID := fileEncryptionKeyID(dst)
+
+	knowledges, err := s.repo.ListKnowledgeByKnowledgeBaseID(ctx, tenantID, kbID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.FileMigrationResult{TargetProvider: target, FailedKnowledgeIDs: []string{}}
+	var pending []*types.Knowledge
+	for _, k := range knowledges {
+		if k.FilePath == "" {
+			continue
+		}
+		if types.InferStorageFromFilePath(k.FilePath) == target {
+			result.Skipped++
+			continue
+		}
+		pending = append(pending, k)
+	}
+	if req.DryRun {
+		result.Migrated = len(pending)
+		return result, nil
+	}
+
+	var (
+		mu    sync.Mutex
+		moves []types.KnowledgeFileMove
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(fileMigrationConcurrency)
+	for _, k := range pending {
+		g.Go(func() error {
+			src := s.resolveFileServiceForPath(gctx, kb, k.FilePath)
+			newPath, err := copyKnowledgeFile(gctx, src, dst, k)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				logger.Errorf(gctx, "Failed to migrate file of knowledge %s to %s: %v", k.ID, target, err)
+				result.FailedKnowledgeIDs = append(result.FailedKnowledgeIDs, k.ID)
+				return nil
+			}
+			moves = append(moves, types.KnowledgeFileMove{
+				KnowledgeID:     k.ID,
+				OldPath:         k.FilePath,
+				NewPath:         newPath,
+				EncryptionKeyID: keyID,
+			})
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	// Cleanup must run even when the request was cancelled mid-copy.
+	cleanupCtx := context.WithoutCancel(ctx)
+	if len(result.FailedKnowledgeIDs) > 0 {
+		for _, m := range moves {
+			if err := dst.DeleteFile(cleanupCtx, m.NewPath); err != nil {
+				logger.Warnf(ctx, "Failed to remove migrated copy %s: %v", m.NewPath, err)
+			}
+		}
+		logger.Warnf(ctx, "File migration of knowledge base %s to %s aborted: %d of %d files failed",
+			kbID, target, len(result.FailedKnowledgeIDs), len(pending))
+		return result, nil
+	}
+
+	stale, err := s.repo.RewriteKnowledgeFilePaths(cleanupCtx, kbID, target, moves)
+	if err != nil {
+		for _, m := range moves {
+			_ = dst.DeleteFile(cleanupCtx, m.NewPath)
+		}
+		return nil, err
+	}
+	result.Committed = true
+
+	byID := make(map[string]*types.Knowledge, len(pending))
+	for _, k := range pending {
+		byID[k.ID] = k
+	}
+	for _, m := range moves {
+		if slices.Contains(stale, m.KnowledgeID) {
+			// The knowledge changed or went away while its file was copied.
+			_ = dst.DeleteFile(cleanupCtx, m.NewPath)
+			result.Skipped++
+			continue
+		}
+		result.Migrated++
+		k := byID[m.KnowledgeID]
+		if req.DeleteSource {
+			src := s.resolveFileServiceForPath(cleanupCtx, kb, m.OldPath)
+			if err := src.DeleteFile(cleanupCtx, m.OldPath); err != nil {
+				logger.Warnf(ctx, "Failed to delete migrated source file %s: %v", m.OldPath, err)
+			}
+			deleteKnowledgePreview(cleanupCtx, src, k)
+		}
+		// The preview stayed behind with the old file; render a new one.
+		s.enqueueFilePreview(cleanupCtx, &types.Knowledge{
+			ID: k.ID, TenantID: k.TenantID, FileType: k.FileType, FileSize: k.FileSize, FilePath: m.NewPath,
+		})
+	}
+
+	logger.Infof(ctx, "Migrated knowledge base %s files to %s: %d moved, %d skipped",
+		kbID, target, result.Migrated, result.Skipped)
+	return result, nil
+}
+
+// copyKnowledgeFile copies k's file from src to dst under k's tenant and ID
+// and verifies the copy by reading it back. It returns the new path.
+func copyKnowledgeFile(ctx context.Context,
+	src, dst interfaces.FileService, k *types.Knowledge,
+) (string, error) {
+	reader, err := src.GetFile(ctx, k.FilePath)
+	if err != nil {
+		return "", err
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return "", fmt.Errorf("read source: %w", err)
+	}
+	sum := sha256.Sum256(data)
+
+	// Only the extension of the upload name is used for the object key.
+	ext := filepath.Ext(k.FileName)
+	if ext == "" {
+		ext = filepath.Ext(k.FilePath)
+	}
+	header, err := bytesToFileHeader(data, k.ID+ext)
+	if err != nil {
+		return "", err
+	}
+	newPath, err := dst.SaveFile(ctx, header, k.TenantID, k.ID)
+	if err != nil {
+		return "", fmt.Errorf("write target: %w", err)
+	}
+
+	if err := verifyFileChecksum(ctx, dst, newPath, sum); err != nil {
+		_ = dst.DeleteFile(context.WithoutCancel(ctx), newPath)
+		return "", err
+	}
+	return newPath, nil
+}
+
+// verifyFileChecksum reads filePath back from svc and compares its SHA-256
+// with want.
+func verifyFileChecksum(ctx context.Context, svc interfaces.FileService, filePath string, want [sha256.Size]byte) error {
+	reader, err := svc.GetFile(ctx, filePath)
+	if err != nil {
+		return fmt.Errorf("read back target: %w", err)
+	}
+	defer reader.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, reader); err != nil {
+		return fmt.Errorf("read back target: %w", err)
+	}
+	if !bytes.Equal(h.Sum(nil), want[:]) {
+		return fmt.Errorf("checksum mismatch for %s", filePath)
+	}
+	return nil
+}
+
+// providerFileService returns the file service for provider: built from the
+// tenant's storage config when it has a block for provider, or the global
+// service when provider is the one the server runs with (STORAGE_TYPE).
+func (s *knowledgeService) providerFileService(ctx context.Context, provider string) (interfaces.FileService, error) {
+	tenant, _ := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenant != nil && slices.Contains(tenant.StorageEngineConfig.ConfiguredProviders(), provider) {
+		baseDir := strings.TrimSpace(os.Getenv("LOCAL_STORAGE_BASE_DIR"))
+		svc, _, err := filesvc.NewFileServiceFromStorageConfig(provider, tenant.StorageEngineConfig, baseDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create %s file service: %w", provider, err)
+		}
+		return svc, nil
+	}
+	global := strings.ToLower(strings.TrimSpace(os.Getenv("STORAGE_TYPE")))
+	if global == "" {
+		global = "local"
+	}
+	if provider == global && s.fileSvc != nil {
+		return s.fileSvc, nil
+	}
+	return nil, fmt.Errorf("storage provider %q is not configured for this tenant", provider)
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime/multipart"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryFileService stores objects in a map. SaveFile writes minio:// paths
+// so one instance can act as both the source and the target of a migration.
+type memoryFileService struct {
+	interfaces.FileService
+
+	objects map[string]string
+	// corrupt makes SaveFile store altered content for this knowledge ID.
+	corrupt string
+}
+
+func (m *memoryFileService) SaveFile(ctx context.Context,
+	file *multipart.FileHeader, tenantID uint64, knowledgeID string,
+) (string, error) {
+	f, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", err
+	}
+	if knowledgeID == m.corrupt {
+		data = append(data, '!')
+	}
+	path := "minio://bucket/" + knowledgeID + "/" + file.Filename
+	m.objects[path] = string(data)
+	return path, nil
+}
+
+func (m *memoryFileService) GetFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	data, ok := m.objects[filePath]
+	if !ok {
+		return nil, errors.New("not found: " + filePath)
+	}
+	return io.NopCloser(strings.NewReader(data)), nil
+}
+
+func (m *memoryFileService) DeleteFile(ctx context.Context, filePath string) error {
+	delete(m.objects, filePath)
+	return nil
+}
+
+type fileMigrationRepoStub struct {
+	interfaces.KnowledgeRepository
+
+	knowledges []*types.Knowledge
+	provider   string
+	moves      []types.KnowledgeFileMove
+}
+
+func (r *fileMigrationRepoStub) ListKnowledgeByKnowledgeBaseID(
+	ctx context.Context, tenantID uint64, kbID string,
+) ([]*types.Knowledge, error) {
+	return r.knowledges, nil
+}
+
+func (r *fileMigrationRepoStub) RewriteKnowledgeFilePaths(
+	ctx context.Context, kbID string, provider string, moves []types.KnowledgeFileMove,
+) ([]string, error) {
+	r.provider = provider
+	r.moves = moves
+	return nil, nil
+}
+
+func newFileMigrationTest(t *testing.T) (*knowledgeService, *fileMigrationRepoStub, *memoryFileService, context.Context) {
+	t.Helper()
+	t.Setenv("STORAGE_TYPE", "minio")
+	store := &memoryFileService{objects: map[string]string{
+		"local://1/k1/a.pdf":       "first",
+		"local://1/k2/b.txt":       "second",
+		"minio://bucket/k3/c.docx": "third",
+	}}
+	repo := &fileMigrationRepoStub{knowledges: []*types.Knowledge{
+		{ID: "k1", TenantID: 1, FileName: "a.pdf", FilePath: "local://1/k1/a.pdf"},
+		{ID: "k2", TenantID: 1, FileName: "b.txt", FilePath: "local://1/k2/b.txt"},
+		{ID: "k3", TenantID: 1, FileName: "c.docx", FilePath: "minio://bucket/k3/c.docx"},
+		{ID: "k4", TenantID: 1, Type: types.KnowledgeTypeManual},
+	}}
+	svc := &knowledgeService{
+		repo:      repo,
+		kbService: &createKnowledgeFileKBServiceStub{kb: &types.KnowledgeBase{ID: "kb1", TenantID: 1}},
+		fileSvc:   store,
+	}
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(1))
+	return svc, repo, store, ctx
+}
+
+func TestMigrateKnowledgeFilesRewritesPathsAfterVerifiedCopy(t *testing.T) {
+	svc, repo, store, ctx := newFileMigrationTest(t)
+
+	result, err := svc.MigrateKnowledgeFiles(ctx, "kb1", &types.FileMigrationRequest{TargetProvider: "minio", DeleteSource: true})
+	require.NoError(t, err)
+	assert.True(t, result.Committed)
+	assert.Equal(t, 2, result.Migrated)
+	assert.Equal(t, 1, result.Skipped)
+	assert.Empty(t, result.FailedKnowledgeIDs)
+
+	assert.Equal(t, "minio", repo.provider)
+	require.Len(t, repo.moves, 2)
+	for _, m := range repo.moves {
+		assert.True(t, strings.HasPrefix(m.NewPath, "minio://"), m.NewPath)
+		assert.NotContains(t, store.objects, m.OldPath, "source should be deleted")
+	}
+	assert.Len(t, store.objects, 3)
+}
+
+func TestMigrateKnowledgeFilesAbortsOnChecksumMismatch(t *testing.T) {
+	svc, repo, store, ctx := newFileMigrationTest(t)
+	store.corrupt = "k2"
+
+	result, err := svc.MigrateKnowledgeFiles(ctx, "kb1", &types.FileMigrationRequest{TargetProvider: "minio", DeleteSource: true})
+	require.NoError(t, err)
+	assert.False(t, result.Committed)
+	assert.Equal(t, 0, result.Migrated)
+	assert.Equal(t, []string{"k2"}, result.FailedKnowledgeIDs)
+
+	assert.Nil(t, repo.moves, "no path may be rewritten")
+	assert.Len(t, store.objects, 3, "copies must be removed and sources kept")
+	assert.Contains(t, store.objects, "local://1/k1/a.pdf")
+}
+
+func TestMigrateKnowledgeFilesRejectsUnconfiguredProvider(t *testing.T) {
+	svc, _, _, ctx := newFileMigrationTest(t)
+
+	_, err := svc.MigrateKnowledgeFiles(ctx, "kb1", &types.FileMigrationRequest{TargetProvider: "tos"})
+	require.Error(t, err)
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// knowledgeGapService records the queries that searched a knowledge base
+// and retrieved nothing, and reports them grouped by meaning: the gap
+// queries are embedded with the knowledge base's embedding model and
+// clustered by cosine similarity, so ten wordings of one missing topic show
+// up as one entry for the content owners.
+type knowledgeGapService struct {
+	repo         interfaces.KnowledgeGapRepository
+	kbRepo       interfaces.KnowledgeBaseRepository
+	modelService interfaces.ModelService
+	now          func() time.Time
+}
+
+// NewKnowledgeGapService creates the knowledge gap service.
+func NewKnowledgeGapService(
+	repo interfaces.KnowledgeGapRepository,
+	kbRepo interfaces.KnowledgeBaseRepository,
+	modelService interfaces.ModelService,
+) interfaces.KnowledgeGapService {
+	return &knowledgeGapService{
+		repo:         repo,
+		kbRepo:       kbRepo,
+		modelService: modelService,
+		now:          time.Now,
+	}
+}
+
+// RecordGaps records a gap in each knowledge base a zero-result query searched
+func (s *knowledgeGapService) RecordGaps(ctx context.Context, log *types.QueryLog) {
+	gaps := types.NewKnowledgeGaps(log, s.now())
+	if len(gaps) == 0 {
+		return
+	}
+	for _, gap := range gaps {
+		gap.ID = uuid.New().String()
+	}
+	if err := s.repo.RecordGaps(ctx, gaps); err != nil {
+		logger.Warnf(ctx, "Failed to record knowledge gaps of message %s: %v", log.MessageID, err)
+	}
+}
+
+func (s *knowledgeGapService) getKnowledgeBase(ctx context.Context, kbID string) (*types.KnowledgeBase, error) {
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil || kb.TenantID != types.MustTenantIDFromContext(ctx) {
+		return nil, werrors.NewNotFoundError("知识库不存在")
+	}
+	return kb, nil
+}
+
+// GetGapReport clusters the most frequent gaps of a knowledge base. Gaps
+// whose query has not been embedded by the knowledge base's current
+// embedding model are embedded first and the vectors kept for later
+// reports. Without an embedding model each cluster holds one query.
+func (s *knowledgeGapService) GetGapReport(
+	ctx context.Context, kbID string, req *types.KnowledgeGapReportRequest,
+) (*types.KnowledgeGapReport, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	threshold, limit := types.DefaultKnowledgeGapThreshold, types.DefaultKnowledgeGapClusters
+	if req != nil && req.Threshold != 0 {
+		threshold = req.Threshold
+	}
+	if threshold < types.MinKnowledgeGapThreshold || threshold > 1 {
+		return nil, werrors.NewBadRequestError(
+			fmt.Sprintf("threshold 必须在 %.1f 到 1 之间", types.MinKnowledgeGapThreshold))
+	}
+	if req != nil && req.Limit != 0 {
+		limit = req.Limit
+	}
+	if limit < 0 || limit > types.MaxKnowledgeGapClusters {
+		return nil, werrors.NewBadRequestError(
+			fmt.Sprintf("limit 必须在 1 到 %d 之间", types.MaxKnowledgeGapClusters))
+	}
+
+	gaps, total, err := s.repo.ListGaps(ctx, kb.TenantID, kb.ID, types.MaxKnowledgeGapsClustered)
+	if err != nil {
+		return nil, err
+	}
+	report := &types.KnowledgeGapReport{
+		KnowledgeBaseID: kb.ID,
+		Threshold:       threshold,
+		Gaps:            total,
+		Truncated:       total > int64(len(gaps)),
+		Clusters:        []*types.KnowledgeGapCluster{},
+	}
+	if len(gaps) == 0 {
+		return report, nil
+	}
+
+	var vectors [][]float32
+	if report.Clustered = s.embedGaps(ctx, kb, gaps); report.Clustered {
+		vectors = make([][]float32, len(gaps))
+		for i, gap := range gaps {
+			vectors[i] = gap.Embedding
+		}
+	}
+	report.Clusters = clusterKnowledgeGaps(gaps, vectors, threshold)
+	if len(report.Clusters) > limit {
+		report.Clusters = report.Clusters[:limit]
+	}
+	return report, nil
+}
+
+// embedGaps makes sure every gap has an embedding by the knowledge base's
+// embedding model and reports whether they all do.
+func (s *knowledgeGapService) embedGaps(ctx context.Context, kb *types.KnowledgeBase, gaps []*types.KnowledgeGap) bool {
+	modelID := strings.TrimSpace(kb.EmbeddingModelID)
+	if modelID == "" {
+		return false
+	}
+	var missing []*types.KnowledgeGap
+	var queries []string
+	for _, gap := range gaps {
+		if gap.EmbeddingModelID != modelID || len(gap.Embedding) == 0 {
+			missing = append(missing, gap)
+			queries = append(queries, gap.Query)
+		}
+	}
+	if len(missing) == 0 {
+		return true
+	}
+	embedder, err := s.modelService.GetEmbeddingModel(ctx, modelID)
+	if err != nil {
+		logger.Warnf(ctx, "Knowledge gaps: embedding model of knowledge base %s unavailable: %v", kb.ID, err)
+		return false
+	}
+	vectors, err := embedder.BatchEmbed(ctx, queries)
+	if err != nil || len(vectors) != len(missing) {
+		logger.Warnf(ctx, "Knowledge gaps: failed to embed %d queries of knowledge base %s: %v",
+			len(missing), kb.ID, err)
+		return false
+	}
+	for i, gap := range missing {
+		gap.Embedding = vectors[i]
+		gap.EmbeddingModelID = modelID
+	}
+	if err := s.repo.SaveEmbeddings(ctx, missing); err != nil {
+		logger.Warnf(ctx, "Knowledge gaps: failed to save query embeddings of knowledge base %s: %v", kb.ID, err)
+	}
+	return true
+}
+
+// clusterKnowledgeGaps groups gaps whose query embeddings are at least
+// threshold apart in cosine similarity. Gaps are taken in order (most
+// frequent first) as cluster seeds and each gap joins the first seed close
+// enough to it. Without vectors each gap is its own cluster.
+func clusterKnowledgeGaps(
+	gaps []*types.KnowledgeGap, vectors [][]float32, threshold float64,
+) []*types.KnowledgeGapCluster {
+	clusters := make([]*types.KnowledgeGapCluster, 0, len(gaps))
+	assigned := make([]bool, len(gaps))
+	for i := range gaps {
+		if assigned[i] {
+			continue
+		}
+		members := []*types.KnowledgeGap{gaps[i]}
+		assigned[i] = true
+		for j := i + 1; j < len(gaps) && vectors != nil; j++ {
+			if !assigned[j] && cosineSimilarity(vectors[i], vectors[j]) >= threshold {
+				members = append(members, gaps[j])
+				assigned[j] = true
+			}
+		}
+		clusters = append(clusters, types.NewKnowledgeGapCluster(members))
+	}
+	types.SortKnowledgeGapClusters(clusters)
+	return clusters
+}
+
+// DismissGaps deletes gaps of a knowledge base
+func (s *knowledgeGapService) DismissGaps(ctx context.Context, kbID string, gapIDs []string) (int64, error) {
+	kb, err := s.getKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return 0, err
+	}
+	return s.repo.DeleteGaps(ctx, kb.TenantID, kb.ID, gapIDs)
+}
@@ -171,7 +171,8 @@ func (s *KnowledgePostProcessService) Handle(ctx context.Context, task *asynq.Ta
 	var questionChunks []*types.Chunk
 	if willSpawnQuestion {
 		for _, c := range textChunks {
-			if c.ChunkType == types.ChunkTypeText {
+			// Chunks kept from a previous file version already have their questions.
+			if c.ChunkType == types.ChunkTypeText && len(chunkQuestionSourceIDs(c)) == 0 {
 				questionChunks = append(questionChunks, c)
 			}
 		}
@@ -473,19 +474,40 @@ func (s *KnowledgePostProcessService) enqueueQuestionGenerationTasks(
 	attempt int,
 	questionChunks []*types.Chunk,
 ) int {
-	if s.taskEnqueuer == nil || len(questionChunks) == 0 {
+	if s.taskEnqueuer == nil || !qg.Enabled {
 		return 0
 	}
-	if !qg.Enabled {
+	return enqueueQuestionBatches(ctx, s.taskEnqueuer, types.QuestionGenerationPayload{
+		TenantID:        payload.TenantID,
+		KnowledgeBaseID: payload.KnowledgeBaseID,
+		KnowledgeID:     payload.KnowledgeID,
+		QuestionCount:   qg.QuestionCount,
+		Language:        payload.Language,
+		Attempt:         attempt,
+		ModelID:         qg.ModelID,
+	}, questionChunks)
+}
+
+// enqueueQuestionBatches enqueues the question generation tasks of
+// questionChunks (text chunks sorted by StartAt), one per batch of
+// questionGenChunkBatchSize chunks. base carries the fields shared by every
+// batch; its question count is clamped to 1..10 (default 3). Shared by the
+// post-process fan-out and explicit regeneration.
+func enqueueQuestionBatches(
+	ctx context.Context,
+	enqueuer interfaces.TaskEnqueuer,
+	base types.QuestionGenerationPayload,
+	questionChunks []*types.Chunk,
+) int {
+	if enqueuer == nil || len(questionChunks) == 0 {
 		return 0
 	}
 
-	questionCount := qg.QuestionCount
-	if questionCount <= 0 {
-		questionCount = 3
+	if base.QuestionCount <= 0 {
+		base.QuestionCount = 3
 	}
-	if questionCount > 10 {
-		questionCount = 10
+	if base.QuestionCount > 10 {
+		base.QuestionCount = 10
 	}
 
 	total := len(questionChunks)
@@ -502,16 +524,9 @@ func (s *KnowledgePostProcessService) enqueueQuestionGenerationTasks(
 			chunkIDs[i] = c.ID
 		}
 
-		taskPayload := types.QuestionGenerationPayload{
-			TenantID:        payload.TenantID,
-			KnowledgeBaseID: payload.KnowledgeBaseID,
-			KnowledgeID:     payload.KnowledgeID,
-			QuestionCount:   questionCount,
-			Language:        payload.Language,
-			Attempt:         attempt,
-			ChunkIDs:        chunkIDs,
-			BatchIndex:      batchIndex,
-		}
+		taskPayload := base
+		taskPayload.ChunkIDs = chunkIDs
+		taskPayload.BatchIndex = batchIndex
 		// Boundary context: the text chunk just before / after this window.
 		if start > 0 {
 			taskPayload.PrevChunkID = questionChunks[start-1].ID
@@ -529,13 +544,13 @@ func (s *KnowledgePostProcessService) enqueueQuestionGenerationTasks(
 		}
 
 		task := asynq.NewTask(types.TypeQuestionGeneration, payloadBytes, asynq.Queue(types.QueueQuestion), asynq.MaxRetry(3))
-		if _, err := s.taskEnqueuer.Enqueue(task); err != nil {
-			logger.Warnf(ctx, "[KnowledgePostProcess] Failed to enqueue question generation batch %d for %s: %v", batchIndex-1, payload.KnowledgeID, err)
+		if _, err := enqueuer.Enqueue(task); err != nil {
+			logger.Warnf(ctx, "[KnowledgePostProcess] Failed to enqueue question generation batch %d for %s: %v", batchIndex-1, base.KnowledgeID, err)
 			continue
 		}
 		enqueued++
 	}
-	logger.Infof(ctx, "[KnowledgePostProcess] Enqueued %d question generation batch tasks (%d chunks, batch_size=%d) for %s (count=%d)",
-		enqueued, total, questionGenChunkBatchSize, payload.KnowledgeID, questionCount)
+	logger.Infof(ctx, "[KnowledgePostProcess] Enqueued %d question generation batch tasks (%d chunks, batch_size=%d) for %s (count=%d, regenerate=%v)",
+		enqueued, total, questionGenChunkBatchSize, base.KnowledgeID, base.QuestionCount, base.Regenerate)
 	return enqueued
 }
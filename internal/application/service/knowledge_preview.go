@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	filesvc "github.com/Tencent/WeKnora/internal/application/service/file"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// enqueueFilePreview schedules thumbnail generation for the knowledge's file
+// when its type supports one. Previews are best effort: failing to enqueue
+// is logged and never fails the upload.
+func (s *knowledgeService) enqueueFilePreview(ctx context.Context, knowledge *types.Knowledge) {
+	if knowledge.FilePath == "" || knowledge.FileSize > filesvc.PreviewMaxSourceBytes ||
+		!filesvc.PreviewSupported(knowledge.FileType) {
+		return
+	}
+	payload := types.FilePreviewPayload{TenantID: knowledge.TenantID, KnowledgeID: knowledge.ID}
+	langfuse.InjectTracing(ctx, &payload)
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to marshal file preview payload: %v", err)
+		return
+	}
+	task := asynq.NewTask(types.TypeFilePreview, payloadBytes,
+		asynq.Queue(types.QueueLow), asynq.MaxRetry(2), asynq.Timeout(2*time.Minute))
+	if _, err := s.task.Enqueue(task); err != nil {
+		logger.Warnf(ctx, "Failed to enqueue file preview task for knowledge %s: %v", knowledge.ID, err)
+	}
+}
+
+// ProcessFilePreview handles Asynq file preview tasks: it renders a
+// thumbnail of an uploaded image, or of a PDF's first page, stores it next
+// to the file as {key}.thumb.jpg and records its path on the knowledge.
+func (s *knowledgeService) ProcessFilePreview(ctx context.Context, t *asynq.Task) error {
+	var payload types.FilePreviewPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "Failed to unmarshal file preview payload: %v", err)
+		return nil // Don't retry on unmarshal error
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, payload.TenantID, payload.KnowledgeID)
+	if errors.Is(err, repository.ErrKnowledgeNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if knowledge.FilePath == "" {
+		return nil
+	}
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, knowledge.KnowledgeBaseID)
+	if err != nil {
+		return err
+	}
+	fileSvc := s.resolveFileServiceForPath(ctx, kb, knowledge.FilePath)
+
+	reader, err := fileSvc.GetFile(ctx, knowledge.FilePath)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(io.LimitReader(reader, filesvc.PreviewMaxSourceBytes+1))
+	reader.Close()
+	if err != nil {
+		return err
+	}
+	if len(data) > filesvc.PreviewMaxSourceBytes {
+		logger.Infof(ctx, "Skipping preview of knowledge %s: file too large", knowledge.ID)
+		return nil
+	}
+
+	preview, err := filesvc.GeneratePreview(ctx, knowledge.FileType, data)
+	if err != nil {
+		// A file that cannot be rendered now will not render on retry.
+		logger.Warnf(ctx, "Failed to generate preview for knowledge %s: %v", knowledge.ID, err)
+		return nil
+	}
+	previewPath, err := filesvc.SavePreview(ctx, fileSvc, knowledge.FilePath, preview)
+	if errors.Is(err, filesvc.ErrPreviewUnsupported) {
+		logger.Infof(ctx, "Storage of knowledge %s cannot hold previews, skipping", knowledge.ID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.repo.UpdateKnowledgeColumn(ctx, knowledge.ID, "preview_path", previewPath); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Generated preview %s for knowledge %s", previewPath, knowledge.ID)
+	return nil
+}
+
+// GetKnowledgePreviewURL returns a download URL for the knowledge's preview,
+// or "" when it has none. For local storage without APP_EXTERNAL_URL the
+// URL is the preview's local:// path, which only GetKnowledgePreview serves.
+func (s *knowledgeService) GetKnowledgePreviewURL(ctx context.Context, id string) (string, error) {
+	knowledge, fileSvc, err := s.knowledgePreviewFileService(ctx, id)
+	if err != nil || knowledge.PreviewPath == "" {
+		return "", err
+	}
+	return fileSvc.GetFileURL(ctx, knowledge.PreviewPath)
+}
+
+// GetKnowledgePreview opens the knowledge's preview image. It fails with a
+// not-found error when none has been generated.
+func (s *knowledgeService) GetKnowledgePreview(ctx context.Context, id string) (io.ReadCloser, error) {
+	knowledge, fileSvc, err := s.knowledgePreviewFileService(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if knowledge.PreviewPath == "" {
+		return nil, werrors.NewNotFoundError("knowledge has no preview")
+	}
+	return fileSvc.GetFile(ctx, knowledge.PreviewPath)
+}
+
+func (s *knowledgeService) knowledgePreviewFileService(ctx context.Context,
+	id string,
+) (*types.Knowledge, interfaces.FileService, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if knowledge.PreviewPath == "" {
+		return knowledge, nil, nil
+	}
+	kb, _ := s.kbService.GetKnowledgeBaseByID(ctx, knowledge.KnowledgeBaseID)
+	return knowledge, s.resolveFileServiceForPath(ctx, kb, knowledge.PreviewPath), nil
+}
+
+// deleteKnowledgePreview removes the preview stored next to a deleted
+// knowledge's file.
+func deleteKnowledgePreview(ctx context.Context, fileSvc interfaces.FileService, knowledge *types.Knowledge) {
+	if knowledge.PreviewPath == "" {
+		return
+	}
+	if err := fileSvc.DeleteFile(ctx, knowledge.PreviewPath); err != nil {
+		logger.Warnf(ctx, "Failed to delete preview %s: %v", knowledge.PreviewPath, err)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/infrastructure/chunker"
 	"github.com/Tencent/WeKnora/internal/infrastructure/docparser"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/asr"
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/searchutil"
@@ -75,6 +76,7 @@ func (s *knowledgeService) cloneKnowledge(
 			return fmt.Errorf("clone knowledge file copy failed: %w", copyErr)
 		}
 		dst.FilePath = newPath
+		dst.EncryptionKeyID = fileEncryptionKeyID(dstSvc)
 		copiedFilePaths = append(copiedFilePaths, newPath)
 	}
 
@@ -162,6 +164,10 @@ type ProcessChunksOptions struct {
 	// child's ParentIndex references an entry in this slice.
 	ParentChunks []types.ParsedParentChunk
 	Metadata     map[string]string
+	// VersionID is the knowledge version being indexed when a new file was
+	// uploaded over an existing knowledge. Chunks whose content hash matches
+	// a chunk of the previous version keep their ID and vectors.
+	VersionID string
 }
 
 // finalizeIndexedKnowledgeState makes a document retrievable as soon as chunks
@@ -281,11 +287,29 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 		logger.Infof(ctx, "Vector/keyword indexing disabled for KB %s, skipping embedding model", kb.ID)
 	}
 
+	// A new file version of the knowledge keeps the chunks that did not
+	// change (see knowledge_version.go). Parent-child chunks and extracted
+	// images are rebuilt in full.
+	var previousChunks []*types.Chunk
+	var previousStorage int64
+	incremental := false
+	if options.VersionID != "" {
+		previousStorage = knowledge.StorageSize
+		var listErr error
+		previousChunks, listErr = s.chunkService.ListChunksByKnowledgeID(ctx, knowledge.ID)
+		if listErr != nil {
+			logger.Warnf(ctx, "Failed to list chunks of the previous version, reindexing in full: %v", listErr)
+		}
+		incremental = listErr == nil && len(options.ParentChunks) == 0 && len(options.StoredImages) == 0
+	}
+
 	// 幂等性处理：清理旧的chunks和索引数据，避免重复数据
 	logger.Infof(ctx, "Cleaning up existing chunks and index data for knowledge: %s", knowledge.ID)
 
 	// 删除旧的chunks
-	if err := s.chunkService.DeleteChunksByKnowledgeID(ctx, knowledge.ID); err != nil {
+	if incremental {
+		logger.Infof(ctx, "Indexing version %s: keeping unchanged chunks of knowledge %s", options.VersionID, knowledge.ID)
+	} else if err := s.chunkService.DeleteChunksByKnowledgeID(ctx, knowledge.ID); err != nil {
 		logger.Warnf(ctx, "Failed to delete existing chunks (may not exist): %v", err)
 		// 不返回错误，继续处理（可能没有旧数据）
 	}
@@ -294,7 +318,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
 	retrieveEngine, err := retriever.CreateRetrieveEngineForKB(
 		ctx, s.retrieveEngine, s.ownership, tenantInfo.ID, kb.VectorStoreID)
-	if err == nil && embeddingModel != nil {
+	if err == nil && embeddingModel != nil && !incremental {
 		if err := retrieveEngine.DeleteByKnowledgeIDList(ctx, []string{knowledge.ID}, embeddingModel.GetDimensions(), knowledge.Type); err != nil {
 			logger.Warnf(ctx, "Failed to delete existing index data (may not exist): %v", err)
 			// 不返回错误，继续处理（可能没有旧数据）
@@ -441,6 +465,11 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 		if hasParentChild && chunkData.ParentIndex >= 0 && chunkData.ParentIndex < len(parentDBChunks) {
 			textChunk.ParentChunkID = parentDBChunks[chunkData.ParentIndex].ID
 		}
+		if chunkData.Metadata != nil {
+			if err := textChunk.SetDocumentMetadata(chunkData.Metadata); err != nil {
+				logger.Warnf(ctx, "Failed to set metadata of chunk %d: %v", idx, err)
+			}
+		}
 
 		chunks[idx].ChunkID = textChunk.ID
 		insertChunks = append(insertChunks, textChunk)
@@ -463,6 +492,22 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 		}
 	}
 
+	// The content hash identifies the chunk across versions of the document.
+	titlePrefix := ""
+	if t := strings.TrimSpace(knowledge.Title); t != "" {
+		titlePrefix = t + "\n"
+	}
+	for _, chunk := range textChunks {
+		chunk.ContentHash = types.DocumentChunkHash(titlePrefix, chunk)
+	}
+	var diff versionChunkDiff
+	if incremental {
+		textChunks, diff = diffVersionChunks(previousChunks, textChunks)
+		insertChunks = diff.created(textChunks)
+		logger.Infof(ctx, "Version %s of knowledge %s: %d chunks reused, %d added, %d removed",
+			options.VersionID, knowledge.ID, len(diff.reused), len(insertChunks), diff.removedTextCount())
+	}
+
 	// 设置文本Chunk之间的前后关系 (skip if parent-child, children don't need prev/next links)
 	if !hasParentChild {
 		for i, chunk := range textChunks {
@@ -488,7 +533,14 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 	s.beginStage(ctx, knowledge.ID, types.StageChunking, types.JSONMap{
 		"chunks_planned": len(insertChunks),
 	})
-	if err := s.chunkService.CreateChunks(ctx, insertChunks); err != nil {
+	var writeErr error
+	if len(insertChunks) > 0 {
+		writeErr = s.chunkService.CreateChunks(ctx, insertChunks)
+	}
+	if writeErr == nil && incremental {
+		writeErr = s.applyVersionChunkDiff(ctx, kb, knowledge, diff, retrieveEngine, embeddingModel)
+	}
+	if err := writeErr; err != nil {
 		knowledge.ParseStatus = types.ParseStatusFailed
 		knowledge.ErrorMessage = err.Error()
 		knowledge.UpdatedAt = time.Now()
@@ -508,10 +560,10 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 
 	// Create index information and perform vector indexing — only when vector/keyword is enabled.
 	// Chunks are ALWAYS saved to DB (above) because wiki and graph need them even without vector indexing.
-	var totalStorageSize int64
+	var totalStorageSize, reusedStorageSize int64
 	if kb.NeedsEmbeddingModel() && embeddingModel != nil {
 		embedInput := types.JSONMap{
-			"chunks_to_embed": len(textChunks),
+			"chunks_to_embed": len(textChunks) - len(diff.reused),
 			"model_id":        kb.EmbeddingModelID,
 		}
 		if dim := embeddingModel.GetDimensions(); dim > 0 {
@@ -523,15 +575,18 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 		// Prepend the document title to improve semantic alignment between
 		// question-style queries and statement-style chunk content.
 		indexInfoList := make([]*types.IndexInfo, 0, len(textChunks))
-		titlePrefix := ""
-		if t := strings.TrimSpace(knowledge.Title); t != "" {
-			titlePrefix = t + "\n"
-		}
+		var reusedIndexInfoList []*types.IndexInfo
 		for _, chunk := range textChunks {
 			// chunk.EmbeddingContent prepends ContextHeader (heading breadcrumb)
 			// when the chunker populated it during Tier-1 splitting; falls back
-			// to plain Content otherwise. Title prefix sits outermost.
-			indexContent := titlePrefix + chunk.EmbeddingContent()
+			// to plain Content otherwise. The contextual retrieval preamble
+			// comes before it and the title prefix sits outermost.
+			indexContent := chunkIndexContent(titlePrefix, chunk)
+			if diff.isReused(chunk.ID) {
+				// Already indexed: only counted for the knowledge's storage size.
+				reusedIndexInfoList = append(reusedIndexInfoList, &types.IndexInfo{Content: indexContent})
+				continue
+			}
 			indexInfoList = append(indexInfoList, &types.IndexInfo{
 				Content:         indexContent,
 				SourceID:        chunk.ID,
@@ -541,10 +596,16 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 				KnowledgeBaseID: knowledge.KnowledgeBaseID,
 				IsEnabled:       true,
 			})
+			if info := tableSummaryIndexInfo(chunk); info != nil {
+				indexInfoList = append(indexInfoList, info)
+			}
 		}
 
 		// Calculate storage size required for embeddings
 		totalStorageSize = retrieveEngine.EstimateStorageSize(ctx, embeddingModel, indexInfoList)
+		if len(reusedIndexInfoList) > 0 {
+			reusedStorageSize = retrieveEngine.EstimateStorageSize(ctx, embeddingModel, reusedIndexInfoList)
+		}
 		if tenantInfo.StorageQuota > 0 {
 			// Re-fetch tenant storage information
 			tenantInfo, err = s.tenantRepo.GetTenantByID(ctx, tenantInfo.ID)
@@ -578,8 +639,11 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 			return
 		}
 
-		err = retrieveEngine.BatchIndex(ctx, embeddingModel, indexInfoList)
-		if err != nil {
+		var indexErr error
+		if len(indexInfoList) > 0 {
+			indexErr = retrieveEngine.BatchIndex(ctx, embeddingModel, indexInfoList)
+		}
+		if err := indexErr; err != nil {
 			knowledge.ParseStatus = types.ParseStatusFailed
 			knowledge.ErrorMessage = err.Error()
 			knowledge.UpdatedAt = time.Now()
@@ -642,7 +706,7 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 	now := time.Now()
 	finalizeIndexedKnowledgeState(
 		knowledge,
-		totalStorageSize,
+		totalStorageSize+reusedStorageSize,
 		len(textChunks),
 		pendingMultimodal || pendingPDFMultimodal,
 		now,
@@ -651,6 +715,14 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 	if err := s.repo.UpdateKnowledge(ctx, knowledge); err != nil {
 		logger.GetLogger(ctx).WithField("error", err).Errorf("processChunks update knowledge failed")
 	}
+	if options.VersionID != "" {
+		removed := countTextChunks(previousChunks)
+		if incremental {
+			removed = diff.removedTextCount()
+		}
+		s.recordVersionChunkStats(ctx, knowledge, options.VersionID,
+			len(textChunks)-len(diff.reused), len(diff.reused), removed)
+	}
 
 	// Enqueue multimodal tasks for images (async, non-blocking)
 	if options.EnableMultimodel && len(options.StoredImages) > 0 {
@@ -685,9 +757,14 @@ func (s *knowledgeService) processChunks(ctx context.Context,
 		}
 	}
 
-	// Update tenant's storage usage
-	tenantInfo.StorageUsed += totalStorageSize
-	if err := s.tenantRepo.AdjustStorageUsed(ctx, tenantInfo.ID, totalStorageSize); err != nil {
+	// Update tenant's storage usage. A new version replaces the storage of
+	// the previous one, which was not released when the file was uploaded.
+	storageDelta := totalStorageSize
+	if options.VersionID != "" {
+		storageDelta = knowledge.StorageSize - previousStorage
+	}
+	tenantInfo.StorageUsed += storageDelta
+	if err := s.tenantRepo.AdjustStorageUsed(ctx, tenantInfo.ID, storageDelta); err != nil {
 		logger.GetLogger(ctx).WithField("error", err).Errorf("processChunks update tenant storage used failed")
 	}
 	logger.GetLogger(ctx).Infof("processChunks successfully")
@@ -1098,6 +1175,9 @@ func (s *knowledgeService) ProcessSummaryGeneration(ctx context.Context, t *asyn
 		summaryErr = err
 		return fmt.Errorf("failed to update knowledge: %w", err)
 	}
+	// File the document under the knowledge base's tags now that its
+	// summary is available to classify on.
+	ScheduleAutoTag(ctx, s.task, kb, knowledge.ID)
 
 	// Create summary chunk and index it — only when RAG indexing is enabled.
 	// Wiki-only KBs don't need summary chunks in the vector index.
@@ -1416,13 +1496,14 @@ func (s *knowledgeService) processQuestionGenerationForKnowledge(ctx context.Con
 	})
 
 	// Initialize chat model
-	chatModel, err := s.modelService.GetChatModel(ctx, kb.SummaryModelID)
+	modelID := questionModelID(payload, kb)
+	chatModel, err := s.modelService.GetChatModel(ctx, modelID)
 	if err != nil {
 		exitStatus = "get_chat_model_failed"
 		logger.Errorf(ctx, "Failed to get chat model: %v", err)
 		return fmt.Errorf("failed to get chat model: %w", err)
 	}
-	resolvedModelID = kb.SummaryModelID
+	resolvedModelID = modelID
 
 	// Initialize embedding model and retrieval engine
 	embeddingModel, err := s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
@@ -1515,9 +1596,7 @@ func (s *knowledgeService) processQuestionGenerationForKnowledge(ctx context.Con
 				Question: question,
 			}
 		}
-		meta := &types.DocumentChunkMetadata{
-			GeneratedQuestions: generatedQuestions,
-		}
+		meta := chunkMetadataWithQuestions(chunk, generatedQuestions)
 		if err := chunk.SetDocumentMetadata(meta); err != nil {
 			chunkMetadataSetFailed++
 			logger.Warnf(ctx, "Failed to set document metadata for chunk %s: %v", chunk.ID, err)
@@ -1610,7 +1689,7 @@ func (s *knowledgeService) processQuestionGenerationForChunks(ctx context.Contex
 	defer func() {
 		finalizeSubtaskDetached(ctx, s.repo, payload.KnowledgeID,
 			fmt.Sprintf("question_batch[%d]", payload.BatchIndex),
-			retErr, superseded, isFinalAsynqAttempt(ctx))
+			retErr, superseded || payload.Regenerate, isFinalAsynqAttempt(ctx))
 	}()
 	defer func() {
 		logger.Infof(ctx,
@@ -1713,13 +1792,14 @@ func (s *knowledgeService) processQuestionGenerationForChunks(ctx context.Contex
 		}
 	}
 
-	chatModel, err := s.modelService.GetChatModel(ctx, kb.SummaryModelID)
+	modelID := questionModelID(payload, kb)
+	chatModel, err := s.modelService.GetChatModel(ctx, modelID)
 	if err != nil {
 		exitStatus = "get_chat_model_failed"
 		logger.Errorf(ctx, "Failed to get chat model: %v", err)
 		return fmt.Errorf("failed to get chat model: %w", err)
 	}
-	resolvedModelID = kb.SummaryModelID
+	resolvedModelID = modelID
 
 	embeddingModel, err := s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
 	if err != nil {
@@ -1808,11 +1888,15 @@ func (s *knowledgeService) processQuestionGenerationForChunks(ctx context.Contex
 	}
 
 	var indexInfoList []*types.IndexInfo
+	// Index entries of the questions being replaced (regeneration, or a retry
+	// of a batch that already stored some), deleted once the new ones are in.
+	var staleSourceIDs []string
 	for i, chunk := range batchChunks {
 		if chunk == nil || strings.TrimSpace(chunk.Content) == "" {
 			emptyChunks++
 			continue
 		}
+		oldQuestions := chunkQuestionSourceIDs(chunk)
 
 		questions, gerr := s.generateQuestionsWithContext(
 			ctx, chatModel, enrich(chunk), prevContentAt(i), nextContentAt(i), knowledge.Title, questionCount)
@@ -1837,7 +1921,7 @@ func (s *knowledgeService) processQuestionGenerationForChunks(ctx context.Contex
 				Question: question,
 			}
 		}
-		meta := &types.DocumentChunkMetadata{GeneratedQuestions: generatedQuestions}
+		meta := chunkMetadataWithQuestions(chunk, generatedQuestions)
 		if err := chunk.SetDocumentMetadata(meta); err != nil {
 			logger.Warnf(ctx, "Failed to set document metadata for chunk %s: %v", chunk.ID, err)
 			continue
@@ -1846,6 +1930,7 @@ func (s *knowledgeService) processQuestionGenerationForChunks(ctx context.Contex
 			logger.Warnf(ctx, "Failed to update chunk %s: %v", chunk.ID, err)
 			continue
 		}
+		staleSourceIDs = append(staleSourceIDs, oldQuestions...)
 		for _, gq := range generatedQuestions {
 			indexInfoList = append(indexInfoList, &types.IndexInfo{
 				Content:         gq.Question,
@@ -1871,9 +1956,41 @@ func (s *knowledgeService) processQuestionGenerationForChunks(ctx context.Contex
 		logger.Infof(ctx, "Indexed %d generated questions for knowledge=%s batch=%d",
 			len(indexInfoList), payload.KnowledgeID, payload.BatchIndex)
 	}
+	if len(staleSourceIDs) > 0 {
+		// The chunks already point at the new questions, so a failure here
+		// only leaves orphan entries behind; don't fail the batch for it.
+		if err := retrieveEngine.DeleteBySourceIDList(ctx, staleSourceIDs,
+			embeddingModel.GetDimensions(), kb.Type); err != nil {
+			logger.Warnf(ctx, "Failed to delete %d replaced question index entries for batch %d: %v",
+				len(staleSourceIDs), payload.BatchIndex, err)
+		}
+	}
 	return nil
 }
 
+// questionModelID returns the chat model generating the questions of a task:
+// the model configured for question generation, else the summary model.
+func questionModelID(payload types.QuestionGenerationPayload, kb *types.KnowledgeBase) string {
+	if payload.ModelID != "" {
+		return payload.ModelID
+	}
+	return kb.SummaryModelID
+}
+
+// chunkQuestionSourceIDs returns the index source ids of the questions
+// currently stored on a chunk.
+func chunkQuestionSourceIDs(chunk *types.Chunk) []string {
+	meta, err := chunk.DocumentMetadata()
+	if err != nil || meta == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(meta.GeneratedQuestions))
+	for _, q := range meta.GeneratedQuestions {
+		ids = append(ids, fmt.Sprintf("%s-%s", chunk.ID, q.ID))
+	}
+	return ids
+}
+
 // generateQuestionsWithContext generates questions for a chunk with surrounding context
 func (s *knowledgeService) generateQuestionsWithContext(ctx context.Context,
 	chatModel chat.Chat, content, prevContent, nextContent, docName string, questionCount int,
@@ -1945,6 +2062,18 @@ func (s *knowledgeService) generateQuestionsWithContext(ctx context.Context,
 	return questions, nil
 }
 
+// chunkMetadataWithQuestions returns the chunk's document metadata with its
+// generated questions replaced, keeping the other fields (time range, layout
+// regions, table) set when the chunk was created.
+func chunkMetadataWithQuestions(chunk *types.Chunk, questions []types.GeneratedQuestion) *types.DocumentChunkMetadata {
+	meta, err := chunk.DocumentMetadata()
+	if err != nil || meta == nil {
+		meta = &types.DocumentChunkMetadata{}
+	}
+	meta.GeneratedQuestions = questions
+	return meta
+}
+
 // ReparseKnowledge deletes existing document content and re-parses the knowledge asynchronously.
 // This method reuses the logic from UpdateManualKnowledge for resource cleanup and async parsing.
 func (s *knowledgeService) ReparseKnowledge(
@@ -1960,6 +2089,12 @@ func (s *knowledgeService) ReparseKnowledge(
 		logger.Errorf(ctx, "Failed to load knowledge: %v", err)
 		return nil, err
 	}
+	if existing.ParseStatus == types.ParseStatusQuarantined {
+		return nil, werrors.NewBadRequestError("文件未通过安全扫描，已隔离的文档不能重新解析")
+	}
+	if existing.Type == types.KnowledgeTypeImported {
+		return nil, werrors.NewBadRequestError("导入的分块数据没有源文件，不能重新解析，请重新导入")
+	}
 
 	// Allocate a fresh span tree attempt up front. Doing this BEFORE
 	// the cleanup + enqueue means: (a) the UI immediately sees a new
@@ -2121,7 +2256,7 @@ func (s *knowledgeService) ReparseKnowledge(
 		task := asynq.NewTask(
 			types.TypeDocumentProcess,
 			payloadBytes,
-			documentProcessTaskOptions(s.config, asynq.MaxRetry(3))...,
+			documentProcessTaskOptions(ctx, s.config, asynq.MaxRetry(3))...,
 		)
 		info, err := s.task.Enqueue(task)
 		if err != nil {
@@ -2174,7 +2309,7 @@ func (s *knowledgeService) ReparseKnowledge(
 		task := asynq.NewTask(
 			types.TypeDocumentProcess,
 			payloadBytes,
-			documentProcessTaskOptions(s.config)...,
+			documentProcessTaskOptions(ctx, s.config)...,
 		)
 		info, err := s.task.Enqueue(task)
 		if err != nil {
@@ -2220,7 +2355,7 @@ func (s *knowledgeService) ReparseKnowledge(
 		task := asynq.NewTask(
 			types.TypeDocumentProcess,
 			payloadBytes,
-			documentProcessTaskOptions(s.config, asynq.MaxRetry(3))...,
+			documentProcessTaskOptions(ctx, s.config, asynq.MaxRetry(3))...,
 		)
 		info, err := s.task.Enqueue(task)
 		if err != nil {
@@ -2779,8 +2914,9 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 	}
 	ctx = withAttempt(ctx, attempt)
 
-	// 检查多模态配置（仅对文件导入）
-	if payload.FilePath != "" && !payload.EnableMultimodel && IsImageType(payload.FileType) {
+	// 检查多模态配置（仅对文件导入）；配置了 OCR 服务时图片改由 OCR 识别
+	if payload.FilePath != "" && !payload.EnableMultimodel && IsImageType(payload.FileType) &&
+		docparser.NewOCRClient(s.knowledgeParserEngineOverrides(ctx, knowledge)) == nil {
 		logger.GetLogger(ctx).WithField("knowledge_id", knowledge.ID).
 			WithField("error", ErrImageNotParse).Errorf("processDocument image without enable multimodel")
 		knowledge.ParseStatus = "failed"
@@ -2791,18 +2927,18 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 	}
 
 	// 检查音频ASR配置（仅对文件导入）
-	if payload.FilePath != "" && IsAudioType(payload.FileType) && !eff.ASRConfig.IsASREnabled() {
+	if payload.FilePath != "" && IsTranscribableType(payload.FileType) && !eff.ASRConfig.IsASREnabled() {
 		logger.GetLogger(ctx).WithField("knowledge_id", knowledge.ID).
 			Errorf("processDocument audio without ASR model configured")
 		knowledge.ParseStatus = "failed"
-		knowledge.ErrorMessage = "上传音频文件需要设置ASR语音识别模型"
+		knowledge.ErrorMessage = "上传音视频文件需要设置ASR语音识别模型"
 		knowledge.UpdatedAt = time.Now()
 		s.repo.UpdateKnowledge(ctx, knowledge)
 		return nil
 	}
 
-	// 视频文件不再支持入库解析
-	if payload.FilePath != "" && IsVideoType(payload.FileType) {
+	// 除可直接转写的视频格式（mp4、webm）外，视频文件不支持入库解析
+	if payload.FilePath != "" && IsVideoType(payload.FileType) && !IsTranscribableType(payload.FileType) {
 		logger.GetLogger(ctx).WithField("knowledge_id", knowledge.ID).
 			Errorf("processDocument video not supported")
 		knowledge.ParseStatus = "failed"
@@ -2936,6 +3072,7 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 	}
 
 	// Step 1.5: ASR transcription for audio files
+	var transcriptChunks []types.ParsedChunk
 	if convertResult != nil && convertResult.IsAudio && len(convertResult.AudioData) > 0 {
 		if !eff.ASRConfig.IsASREnabled() {
 			logger.Error(ctx, "Audio file detected but ASR is not configured")
@@ -2974,6 +3111,12 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 		var transcribedText string
 		if transcriptionResult != nil {
 			transcribedText = transcriptionResult.Text
+			// Timestamped segments are chunked by speaker turn and time window
+			// instead of by the text splitter, so every chunk keeps its start time.
+			transcribedText, transcriptChunks = transcriptToChunks(asr.ChunkTranscript(transcriptionResult, asr.ChunkOptions{
+				MaxSeconds: float64(eff.ASRConfig.ChunkSeconds),
+				MaxChars:   eff.ChunkingConfig.ChunkSize,
+			}), transcribedText)
 		}
 
 		if transcribedText == "" {
@@ -2981,7 +3124,8 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 			transcribedText = "[No speech detected in audio file]"
 		}
 
-		logger.Infof(ctx, "[ASR] Transcription completed, text length=%d", len(transcribedText))
+		logger.Infof(ctx, "[ASR] Transcription completed, text length=%d, transcript chunks=%d",
+			len(transcribedText), len(transcriptChunks))
 		// Replace the audio placeholder with the transcribed text
 		convertResult.MarkdownContent = transcribedText
 		convertResult.IsAudio = false
@@ -3026,13 +3170,22 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 		QuestionCount:            payload.QuestionCount,
 		EnableMultimodel:         payload.EnableMultimodel,
 		StoredImages:             storedImages,
+		VersionID:                payload.VersionID,
 	}
 
 	if convertResult != nil {
 		processOpts.Metadata = convertResult.Metadata
 	}
 
-	if eff.ChunkingConfig.EnableParentChild {
+	if len(transcriptChunks) > 0 {
+		chunks = transcriptChunks
+		logger.Infof(ctx, "Split transcript into %d timestamped chunks for knowledge %s", len(chunks), knowledge.ID)
+	} else if docparser.CodeLanguage(knowledge.FileType) != "" {
+		// Source files are split by symbol whatever the chunking strategy;
+		// the file name holds the repository path.
+		chunks = splitCodeChunks(convertResult.MarkdownContent, knowledge.FileName, chunkCfg)
+		logger.Infof(ctx, "Split source file into %d symbol chunks for knowledge %s", len(chunks), knowledge.ID)
+	} else if eff.ChunkingConfig.EnableParentChild {
 		parentCfg, childCfg := buildParentChildConfigs(eff.ChunkingConfig, chunkCfg)
 		pcResult := chunker.SplitParentChild(convertResult.MarkdownContent, parentCfg, childCfg)
 		chunks = make([]types.ParsedChunk, len(pcResult.Children))
@@ -3044,6 +3197,7 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 				Start:         c.Start,
 				End:           c.End,
 				ParentIndex:   c.ParentIndex,
+				Metadata:      splitChunkMetadata(c.Chunk),
 			}
 		}
 		parentChunks := make([]types.ParsedParentChunk, len(pcResult.Parents))
@@ -3054,18 +3208,19 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 		logger.Infof(ctx, "Split document into %d parent + %d child chunks for knowledge %s",
 			len(pcResult.Parents), len(pcResult.Children), knowledge.ID)
 	} else {
-		splitChunks := chunker.Split(convertResult.MarkdownContent, chunkCfg)
-		chunks = make([]types.ParsedChunk, len(splitChunks))
-		for i, c := range splitChunks {
-			chunks[i] = types.ParsedChunk{
-				Content:       c.Content,
-				ContextHeader: c.ContextHeader,
-				Seq:           c.Seq,
-				Start:         c.Start,
-				End:           c.End,
-			}
-		}
+		normalizeTables(eff.ChunkingConfig, convertResult)
+		chunks = splitFlatChunks(convertResult.MarkdownContent, eff.ChunkingConfig, chunkCfg)
 		logger.Infof(ctx, "Split document into %d chunks for knowledge %s", len(chunks), knowledge.ID)
+		if eff.ChunkingConfig.TableChunking && eff.ChunkingConfig.TableSummary {
+			s.summarizeTables(ctx, kb, knowledge, chunks)
+		}
+	}
+
+	if convertResult != nil {
+		attachLayoutRegions(chunks, convertResult.LayoutBlocks)
+		if eff.ChunkingConfig.ContextualRetrieval {
+			s.contextualizeChunks(ctx, kb, knowledge, convertResult.MarkdownContent, chunks)
+		}
 	}
 
 	// Step 4: Process chunks (vectorize + index + enqueue async tasks)
@@ -3074,6 +3229,42 @@ func (s *knowledgeService) ProcessDocument(ctx context.Context, t *asynq.Task) e
 	return nil
 }
 
+// transcriptToChunks turns transcript chunks into parsed chunks carrying
+// their time range and speaker as metadata, and returns the transcript text
+// they were cut from. Without transcript chunks (the ASR service returned no
+// segments) the plain text is returned unchanged.
+func transcriptToChunks(transcript []asr.TranscriptChunk, text string) (string, []types.ParsedChunk) {
+	if len(transcript) == 0 {
+		return text, nil
+	}
+	const sep = "\n\n"
+	var b strings.Builder
+	chunks := make([]types.ParsedChunk, len(transcript))
+	offset := 0
+	for i, tc := range transcript {
+		if i > 0 {
+			b.WriteString(sep)
+			offset += len([]rune(sep))
+		}
+		content := tc.Content()
+		b.WriteString(content)
+		start, end := tc.Start, tc.End
+		chunks[i] = types.ParsedChunk{
+			Content: content,
+			Seq:     i,
+			Start:   offset,
+			End:     offset + len([]rune(content)),
+			Metadata: &types.DocumentChunkMetadata{
+				StartTime: &start,
+				EndTime:   &end,
+				Speaker:   tc.Speaker,
+			},
+		}
+		offset = chunks[i].End
+	}
+	return b.String(), chunks
+}
+
 // convert handles both file and URL reading using a unified ReadRequest.
 func (s *knowledgeService) convert(
 	ctx context.Context,
@@ -3093,18 +3284,21 @@ func (s *knowledgeService) convert(
 		"file_type": payload.FileType,
 		"is_url":    payload.URL != "",
 	}
+	// Queue and retry count feed the ingestion job view (priority lane,
+	// retries used so far).
+	if queue, ok := asynq.GetQueueName(ctx); ok {
+		docInput["queue"] = queue
+	}
+	if retry, ok := asynq.GetRetryCount(ctx); ok {
+		docInput["retry"] = retry
+	}
 	if payload.URL != "" {
 		docInput["url"] = payload.URL
 	}
 	s.beginStage(ctx, knowledge.ID, types.StageDocReader, docInput)
 	isURL := payload.URL != ""
 	fileType := payload.FileType
-	tenantOverrides := s.getParserEngineOverridesFromContext(ctx)
-	var uploadOverrides map[string]string
-	if processOverrides, err := knowledge.ProcessOverrides(); err == nil && processOverrides != nil {
-		uploadOverrides = processOverrides.ParserEngineOverrides
-	}
-	mergedOverrides := MergeParserEngineOverrides(tenantOverrides, uploadOverrides)
+	mergedOverrides := s.knowledgeParserEngineOverrides(ctx, knowledge)
 
 	if isURL {
 		if err := secutils.ValidateURLForSSRF(payload.URL); err != nil {
@@ -3191,11 +3385,17 @@ func (s *knowledgeService) convert(
 			werrors.ErrCodeDocReaderParseFailed, result.Error, nil)
 		return nil, nil
 	}
+	if !isURL && docparser.NeedsOCR(fileType, result, payload.EnableMultimodel) {
+		s.applyOCRFallback(ctx, req, result, mergedOverrides)
+	}
 	docOutput := types.JSONMap{
 		"text_length":  len(result.MarkdownContent),
 		"images_found": len(result.ImageRefs),
 		"is_audio":     result.IsAudio,
 	}
+	if len(result.LayoutBlocks) > 0 {
+		docOutput["ocr_blocks"] = len(result.LayoutBlocks)
+	}
 	if pages := result.Metadata["pages"]; pages != "" {
 		docOutput["pages"] = pages
 	}
@@ -3203,6 +3403,57 @@ func (s *knowledgeService) convert(
 	return result, nil
 }
 
+// knowledgeParserEngineOverrides returns the tenant parser engine settings
+// merged with the overrides uploaded with the knowledge.
+func (s *knowledgeService) knowledgeParserEngineOverrides(ctx context.Context, knowledge *types.Knowledge) map[string]string {
+	tenantOverrides := s.getParserEngineOverridesFromContext(ctx)
+	var uploadOverrides map[string]string
+	if processOverrides, err := knowledge.ProcessOverrides(); err == nil && processOverrides != nil {
+		uploadOverrides = processOverrides.ParserEngineOverrides
+	}
+	return MergeParserEngineOverrides(tenantOverrides, uploadOverrides)
+}
+
+// applyOCRFallback runs a scanned document (a PDF without text layer, or an
+// image not parsed by the multimodal pipeline) through the configured OCR
+// service and replaces the parser output with the recognized blocks. OCR
+// failures are logged and leave the parser output unchanged.
+func (s *knowledgeService) applyOCRFallback(
+	ctx context.Context, req *types.ReadRequest, result *types.ReadResult, overrides map[string]string,
+) {
+	ocr := docparser.NewOCRClient(overrides)
+	if ocr == nil {
+		logger.Infof(ctx, "[OCR] %q has no extractable text and no OCR service is configured", req.FileName)
+		return
+	}
+	blocks, err := ocr.Recognize(ctx, req)
+	if err != nil {
+		logger.Warnf(ctx, "[OCR] Recognition failed for %q, keeping parser output: %v", req.FileName, err)
+		return
+	}
+	docparser.ApplyOCR(result, blocks, req.FileType)
+	logger.Infof(ctx, "[OCR] Recognized %d blocks for %q, text length=%d",
+		len(result.LayoutBlocks), req.FileName, len(result.MarkdownContent))
+}
+
+// attachLayoutRegions stores in each chunk's metadata the page regions of
+// the OCR blocks its text was cut from.
+func attachLayoutRegions(chunks []types.ParsedChunk, blocks []types.LayoutBlock) {
+	if len(blocks) == 0 {
+		return
+	}
+	for i := range chunks {
+		regions := docparser.LayoutRegions(blocks, chunks[i].Start, chunks[i].End)
+		if len(regions) == 0 {
+			continue
+		}
+		if chunks[i].Metadata == nil {
+			chunks[i].Metadata = &types.DocumentChunkMetadata{}
+		}
+		chunks[i].Metadata.Regions = regions
+	}
+}
+
 // callDocReaderWithTimeout wraps the DocReader RPC in a child context whose
 // deadline is min(parent_deadline, DocReaderCallTimeout). Without this cap,
 // a hung docreader (network partition, GC pause, OCR runaway) silently
@@ -3396,6 +3647,7 @@ func (s *knowledgeService) ProcessKnowledgeListReparse(ctx context.Context, t *a
 
 	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
 	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenant)
+	ctx = types.WithIngestPriority(ctx, types.IngestPriorityBulk)
 
 	var failed int
 	for _, id := range payload.KnowledgeIDs {
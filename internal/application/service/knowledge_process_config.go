@@ -40,7 +40,13 @@ func ResolveProcessConfig(kb *types.KnowledgeBase, overrides *types.KnowledgePro
 		eff.ASRConfig = *overrides.ASRConfig
 	}
 	if overrides.QuestionGenerationConfig != nil {
+		modelID := eff.QuestionGenerationConfig.ModelID
 		eff.QuestionGenerationConfig = *overrides.QuestionGenerationConfig
+		// Overrides saved before the model was configurable carry no model;
+		// keep the knowledge base's.
+		if eff.QuestionGenerationConfig.ModelID == "" {
+			eff.QuestionGenerationConfig.ModelID = modelID
+		}
 	}
 	if overrides.GraphEnabled != nil {
 		eff.GraphEnabled = *overrides.GraphEnabled
@@ -72,7 +78,7 @@ func ValidateProcessOverrides(
 		if IsImageType(ft) {
 			hasImage = true
 		}
-		if IsAudioType(ft) {
+		if IsTranscribableType(ft) {
 			hasAudio = true
 		}
 	}
@@ -89,7 +95,7 @@ func ValidateProcessOverrides(
 	}
 
 	if hasAudio && !eff.ASRConfig.IsASREnabled() {
-		return werrors.NewBadRequestError("上传音频文件需要设置ASR语音识别模型")
+		return werrors.NewBadRequestError("上传音视频文件需要设置ASR语音识别模型")
 	}
 
 	return nil
@@ -189,6 +195,11 @@ func mergeChunkingConfig(base types.ChunkingConfig, override *types.ChunkingConf
 	if len(override.Languages) > 0 {
 		result.Languages = override.Languages
 	}
+	// Like EnableParentChild, the table and contextual retrieval flags come
+	// with the full snapshot.
+	result.TableChunking = override.TableChunking
+	result.TableSummary = override.TableSummary
+	result.ContextualRetrieval = override.ContextualRetrieval
 	return result
 }
 
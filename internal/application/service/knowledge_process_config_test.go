@@ -177,6 +177,24 @@ func TestResolveProcessConfig_EnableMultimodelOverride(t *testing.T) {
 	require.False(t, eff.EnableMultimodel)
 }
 
+func TestResolveProcessConfig_QuestionGenerationKeepsKBModel(t *testing.T) {
+	t.Parallel()
+
+	kb := &types.KnowledgeBase{
+		QuestionGenerationConfig: &types.QuestionGenerationConfig{Enabled: true, QuestionCount: 3, ModelID: "qa-1"},
+	}
+	eff := ResolveProcessConfig(kb, &types.KnowledgeProcessOverrides{
+		QuestionGenerationConfig: &types.QuestionGenerationConfig{Enabled: true, QuestionCount: 5},
+	})
+	require.Equal(t, 5, eff.QuestionGenerationConfig.QuestionCount)
+	require.Equal(t, "qa-1", eff.QuestionGenerationConfig.ModelID)
+
+	eff = ResolveProcessConfig(kb, &types.KnowledgeProcessOverrides{
+		QuestionGenerationConfig: &types.QuestionGenerationConfig{Enabled: true, QuestionCount: 5, ModelID: "qa-2"},
+	})
+	require.Equal(t, "qa-2", eff.QuestionGenerationConfig.ModelID)
+}
+
 func TestResolveProcessConfig_ExtractConfigFieldMerge(t *testing.T) {
 	t.Parallel()
 
@@ -254,6 +272,14 @@ func TestValidateProcessOverrides_AudioWithEffectiveASR(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestValidateProcessOverrides_VideoRequiresASR(t *testing.T) {
+	t.Parallel()
+
+	kb := &types.KnowledgeBase{}
+	err := ValidateProcessOverrides(context.Background(), kb, &types.KnowledgeProcessOverrides{}, []string{"mp4"})
+	require.Error(t, err)
+}
+
 func TestValidateProcessOverrides_NonMediaFileTypes(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// RegenerateQuestions replaces the generated questions of every text chunk
+// of a parsed knowledge using the current question generation settings
+// (count and model), e.g. after they were changed. The work runs in the
+// question generation queue; each batch swaps the chunk questions and their
+// index entries. Returns the number of chunks queued.
+func (s *knowledgeService) RegenerateQuestions(ctx context.Context, knowledgeID string) (int, error) {
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	knowledge, err := s.repo.GetKnowledgeByID(ctx, tenantID, knowledgeID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to load knowledge: %v", err)
+		return 0, err
+	}
+	if knowledge.ParseStatus != types.ParseStatusCompleted {
+		return 0, werrors.NewBadRequestError("文档解析完成后才能重新生成问题")
+	}
+
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, knowledge.KnowledgeBaseID)
+	if err != nil {
+		return 0, err
+	}
+	if !kb.NeedsEmbeddingModel() {
+		return 0, werrors.NewBadRequestError("该知识库未启用向量检索，无法生成问题")
+	}
+	processOverrides, _ := knowledge.ProcessOverrides()
+	qg := ResolveProcessConfig(kb, processOverrides).QuestionGenerationConfig
+	if !qg.Enabled {
+		return 0, werrors.NewBadRequestError("未启用问题生成")
+	}
+
+	chunks, err := s.chunkService.ListChunksByKnowledgeID(ctx, knowledgeID)
+	if err != nil {
+		return 0, err
+	}
+	var questionChunks []*types.Chunk
+	for _, c := range chunks {
+		if c.ChunkType == types.ChunkTypeText {
+			questionChunks = append(questionChunks, c)
+		}
+	}
+	if len(questionChunks) == 0 {
+		return 0, nil
+	}
+	// Same ordering as the post-process fan-out, so each chunk gets the
+	// same surrounding context.
+	sort.Slice(questionChunks, func(i, j int) bool {
+		return questionChunks[i].StartAt < questionChunks[j].StartAt
+	})
+
+	lang, _ := types.LanguageFromContext(ctx)
+	enqueued := enqueueQuestionBatches(ctx, s.task, types.QuestionGenerationPayload{
+		TenantID:        tenantID,
+		KnowledgeBaseID: knowledge.KnowledgeBaseID,
+		KnowledgeID:     knowledge.ID,
+		QuestionCount:   qg.QuestionCount,
+		Language:        lang,
+		ModelID:         qg.ModelID,
+		Regenerate:      true,
+	}, questionChunks)
+	if enqueued == 0 {
+		return 0, werrors.NewInternalServerError("问题生成任务提交失败")
+	}
+	logger.Infof(ctx, "Queued question regeneration for knowledge %s: %d chunks in %d batches",
+		knowledge.ID, len(questionChunks), enqueued)
+	return len(questionChunks), nil
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type questionTaskRecorder struct {
+	payloads []types.QuestionGenerationPayload
+}
+
+func (r *questionTaskRecorder) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	var p types.QuestionGenerationPayload
+	if err := json.Unmarshal(task.Payload(), &p); err != nil {
+		return nil, err
+	}
+	r.payloads = append(r.payloads, p)
+	return &asynq.TaskInfo{ID: fmt.Sprintf("task-%d", len(r.payloads)), Queue: types.QueueQuestion}, nil
+}
+
+func TestEnqueueQuestionBatches(t *testing.T) {
+	chunks := make([]*types.Chunk, questionGenChunkBatchSize+5)
+	for i := range chunks {
+		chunks[i] = &types.Chunk{ID: fmt.Sprintf("c%d", i)}
+	}
+	rec := &questionTaskRecorder{}
+
+	n := enqueueQuestionBatches(context.Background(), rec, types.QuestionGenerationPayload{
+		KnowledgeID:   "k1",
+		QuestionCount: 42,
+		ModelID:       "qa-model",
+		Regenerate:    true,
+	}, chunks)
+
+	require.Equal(t, 2, n)
+	require.Len(t, rec.payloads, 2)
+	first, second := rec.payloads[0], rec.payloads[1]
+	assert.Len(t, first.ChunkIDs, questionGenChunkBatchSize)
+	assert.Equal(t, []string{"c20", "c21", "c22", "c23", "c24"}, second.ChunkIDs)
+	assert.Equal(t, 1, second.BatchIndex)
+	assert.Equal(t, "", first.PrevChunkID)
+	assert.Equal(t, "c20", first.NextChunkID)
+	assert.Equal(t, "c19", second.PrevChunkID)
+	assert.Equal(t, "", second.NextChunkID)
+	for _, p := range rec.payloads {
+		assert.Equal(t, 10, p.QuestionCount)
+		assert.Equal(t, "qa-model", p.ModelID)
+		assert.True(t, p.Regenerate)
+	}
+}
+
+func TestChunkQuestionSourceIDs(t *testing.T) {
+	chunk := &types.Chunk{ID: "c1"}
+	assert.Empty(t, chunkQuestionSourceIDs(chunk))
+
+	require.NoError(t, chunk.SetDocumentMetadata(&types.DocumentChunkMetadata{
+		GeneratedQuestions: []types.GeneratedQuestion{{ID: "q1", Question: "a?"}, {ID: "q2", Question: "b?"}},
+	}))
+	assert.Equal(t, []string{"c1-q1", "c1-q2"}, chunkQuestionSourceIDs(chunk))
+}
+
+func TestQuestionModelID(t *testing.T) {
+	kb := &types.KnowledgeBase{SummaryModelID: "summary"}
+	assert.Equal(t, "summary", questionModelID(types.QuestionGenerationPayload{}, kb))
+	assert.Equal(t, "qa", questionModelID(types.QuestionGenerationPayload{ModelID: "qa"}, kb))
+}
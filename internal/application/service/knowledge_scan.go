@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"mime/multipart"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/infrastructure/filescan"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// uploadScan is the outcome of scanning one upload.
+type uploadScan struct {
+	// quarantined is set when the upload is infected and the tenant keeps
+	// infected uploads for review instead of rejecting them.
+	quarantined bool
+	signature   string
+	// audit is the pending audit record; it is written once the knowledge
+	// ID the upload ends up under is known.
+	audit map[string]any
+	// accepted is the audit outcome: whether the upload was let through.
+	accepted bool
+}
+
+// scanUpload runs the configured malware scanner on file. It returns a
+// BadRequest error for infected uploads the tenant rejects and, unless
+// FILE_SCAN_FAIL_OPEN is set, a ServiceUnavailable error when the scanner
+// cannot be reached. A nil result means scanning is off for the tenant.
+// Rejections are audited here; accepted and quarantined uploads are audited
+// by the caller through recordUploadScan once stored.
+func (s *knowledgeService) scanUpload(ctx context.Context,
+	kbID string, file *multipart.FileHeader, fileName string,
+) (*uploadScan, error) {
+	if s.uploadScanner == nil {
+		return nil, nil
+	}
+	tenant, _ := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	var cfg *types.UploadScanConfig
+	if tenant != nil && tenant.StorageEngineConfig != nil {
+		cfg = tenant.StorageEngineConfig.UploadScan
+	}
+	if !cfg.IsEnabled() {
+		return nil, nil
+	}
+
+	scan := &uploadScan{audit: map[string]any{
+		"file_name":         fileName,
+		"file_size":         file.Size,
+		"knowledge_base_id": kbID,
+		"scanner":           s.uploadScanner.Name(),
+	}}
+
+	result, err := s.scanMultipartFile(ctx, file, fileName)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to scan upload %s: %v", fileName, err)
+		scan.audit["verdict"] = "error"
+		scan.audit["error"] = err.Error()
+		if filescan.FailOpen() {
+			scan.audit["action"] = "allowed"
+			scan.accepted = true
+			return scan, nil
+		}
+		scan.audit["action"] = filescan.ActionReject
+		s.recordUploadScan(ctx, scan, "")
+		return nil, werrors.NewServiceUnavailableError("文件安全扫描服务暂不可用，请稍后重试")
+	}
+
+	scan.audit["verdict"] = string(result.Verdict)
+	if !result.Infected() {
+		scan.audit["action"] = "allowed"
+		scan.accepted = true
+		return scan, nil
+	}
+
+	scan.signature = result.Signature
+	scan.audit["signature"] = result.Signature
+	action := filescan.DefaultAction()
+	if cfg != nil && cfg.Action != "" {
+		action = cfg.Action
+	}
+	logger.Warnf(ctx, "Upload %s to knowledge base %s is infected (%s), action=%s",
+		fileName, kbID, result.Signature, action)
+	scan.audit["action"] = action
+	if action == filescan.ActionQuarantine {
+		scan.quarantined = true
+		return scan, nil
+	}
+	s.recordUploadScan(ctx, scan, "")
+	return nil, werrors.NewBadRequestError("文件未通过安全扫描，已拒绝上传: " + result.Signature)
+}
+
+func (s *knowledgeService) scanMultipartFile(ctx context.Context,
+	file *multipart.FileHeader, fileName string,
+) (*filescan.Result, error) {
+	f, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return s.uploadScanner.Scan(ctx, f, fileName)
+}
+
+// recordUploadScan writes the audit record of a scan. knowledgeID is the
+// knowledge the upload was stored as, empty when it was rejected.
+func (s *knowledgeService) recordUploadScan(ctx context.Context, scan *uploadScan, knowledgeID string) {
+	if s.auditSvc == nil || scan == nil {
+		return
+	}
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	details, err := json.Marshal(scan.audit)
+	if err != nil {
+		details = []byte("{}")
+	}
+	outcome := types.AuditOutcomeDenied
+	if scan.accepted {
+		outcome = types.AuditOutcomeSuccess
+	}
+	if err := s.auditSvc.Log(ctx, &types.AuditLog{
+		TenantID:    tenantID,
+		ActorUserID: auditActor(ctx),
+		ActorRole:   auditActorRole(ctx),
+		Action:      types.AuditActionFileScanned,
+		TargetType:  "knowledge",
+		TargetID:    knowledgeID,
+		Outcome:     outcome,
+		Details:     types.JSON(details),
+	}); err != nil {
+		logger.Warnf(ctx, "Failed to record upload scan audit: %v", err)
+	}
+}
@@ -0,0 +1,999 @@
+package service
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
+)
+
+const (
+	// kbSnapshotPartSize is the compressed size at which an archive part is
+	// flushed to storage and a new part is started.
+	kbSnapshotPartSize = 32 << 20
+	// kbSnapshotExportBatch is the number of index rows read per engine page.
+	kbSnapshotExportBatch = 500
+	// kbRestoreBatch is the number of chunks or index rows written at once.
+	kbRestoreBatch = 200
+)
+
+// errKBSnapshotStop ends a snapshot read early without an error.
+var errKBSnapshotStop = errors.New("stop reading snapshot")
+
+// kbSnapshotWriter encodes archive records as gzip-compressed JSONL and
+// hands each part to save once it reaches partSize compressed bytes.
+type kbSnapshotWriter struct {
+	partSize int
+	save     func(part int, data []byte) (string, error)
+
+	buf   bytes.Buffer
+	gz    *gzip.Writer
+	enc   *json.Encoder
+	dirty bool
+	parts []string
+	size  int64
+}
+
+func newKBSnapshotWriter(partSize int, save func(part int, data []byte) (string, error)) *kbSnapshotWriter {
+	w := &kbSnapshotWriter{partSize: partSize, save: save}
+	w.gz = gzip.NewWriter(&w.buf)
+	w.enc = json.NewEncoder(w.gz)
+	return w
+}
+
+func (w *kbSnapshotWriter) write(rec *types.KBSnapshotRecord) error {
+	if err := w.enc.Encode(rec); err != nil {
+		return fmt.Errorf("failed to encode snapshot record: %w", err)
+	}
+	w.dirty = true
+	if w.buf.Len() >= w.partSize {
+		return w.flush()
+	}
+	return nil
+}
+
+// flush closes the current part and saves it. A part without records is
+// not saved.
+func (w *kbSnapshotWriter) flush() error {
+	if !w.dirty {
+		return nil
+	}
+	if err := w.gz.Close(); err != nil {
+		return fmt.Errorf("failed to compress snapshot part: %w", err)
+	}
+	path, err := w.save(len(w.parts), w.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot part: %w", err)
+	}
+	w.parts = append(w.parts, path)
+	w.size += int64(w.buf.Len())
+	w.buf.Reset()
+	w.gz.Reset(&w.buf)
+	w.dirty = false
+	return nil
+}
+
+// readKBSnapshot decodes the records of the archive parts in order. fn may
+// return errKBSnapshotStop to end the read early.
+func readKBSnapshot(parts []string, open func(path string) (io.ReadCloser, error),
+	fn func(rec *types.KBSnapshotRecord) error,
+) error {
+	for _, path := range parts {
+		err := func() error {
+			file, err := open(path)
+			if err != nil {
+				return fmt.Errorf("failed to open snapshot part %s: %w", path, err)
+			}
+			defer file.Close()
+			gz, err := gzip.NewReader(file)
+			if err != nil {
+				return fmt.Errorf("failed to read snapshot part %s: %w", path, err)
+			}
+			defer gz.Close()
+			dec := json.NewDecoder(gz)
+			for {
+				var rec types.KBSnapshotRecord
+				if err := dec.Decode(&rec); err != nil {
+					if errors.Is(err, io.EOF) {
+						return nil
+					}
+					return fmt.Errorf("failed to decode snapshot part %s: %w", path, err)
+				}
+				if err := fn(&rec); err != nil {
+					return err
+				}
+			}
+		}()
+		if errors.Is(err, errKBSnapshotStop) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateKBSnapshot records a snapshot of a knowledge base and enqueues the
+// task that writes it. The snapshot belongs to the knowledge base's tenant.
+func (s *knowledgeService) CreateKBSnapshot(ctx context.Context, kbID, trigger string) (*types.KBSnapshot, error) {
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	if kb.Type == types.KnowledgeBaseTypeWiki {
+		return nil, werrors.NewBadRequestError("Wiki 知识库暂不支持快照")
+	}
+	if trigger != types.KBSnapshotTriggerScheduled {
+		trigger = types.KBSnapshotTriggerManual
+	}
+
+	snapshot := &types.KBSnapshot{
+		ID:                uuid.New().String(),
+		TenantID:          kb.TenantID,
+		KnowledgeBaseID:   kb.ID,
+		KnowledgeBaseName: kb.Name,
+		Trigger:           trigger,
+		Status:            types.KBSnapshotStatusPending,
+		EmbeddingModelID:  kb.EmbeddingModelID,
+		CreatedAt:         time.Now(),
+	}
+	if err := s.snapshotRepo.CreateSnapshot(ctx, snapshot); err != nil {
+		logger.Errorf(ctx, "Failed to create KB snapshot: %v", err)
+		return nil, err
+	}
+
+	payload := types.KBSnapshotPayload{TenantID: kb.TenantID, SnapshotID: snapshot.ID}
+	langfuse.InjectTracing(ctx, &payload)
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal KB snapshot payload: %w", err)
+	}
+	task := asynq.NewTask(types.TypeKBSnapshot, payloadBytes,
+		asynq.TaskID(snapshot.ID),
+		asynq.Queue(types.QueueLow),
+		asynq.MaxRetry(2),
+		asynq.Timeout(6*time.Hour),
+	)
+	if _, err := s.task.Enqueue(task); err != nil {
+		logger.Errorf(ctx, "Failed to enqueue KB snapshot task: %v", err)
+		snapshot.Status = types.KBSnapshotStatusFailed
+		snapshot.ErrorMessage = err.Error()
+		_ = s.snapshotRepo.UpdateSnapshot(ctx, snapshot)
+		return nil, err
+	}
+	logger.Infof(ctx, "KB snapshot %s enqueued for knowledge base %s (%s)", snapshot.ID, kb.ID, trigger)
+	return snapshot, nil
+}
+
+// ListKBSnapshots lists the snapshots of the current tenant, newest first.
+// An empty kbID lists every knowledge base, including deleted ones.
+func (s *knowledgeService) ListKBSnapshots(ctx context.Context, kbID string) ([]*types.KBSnapshot, error) {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	if kbID != "" {
+		kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+		if err != nil {
+			return nil, err
+		}
+		tenantID = kb.TenantID
+	}
+	return s.snapshotRepo.ListSnapshots(ctx, tenantID, kbID)
+}
+
+// GetKBSnapshot returns a snapshot of the current tenant.
+func (s *knowledgeService) GetKBSnapshot(ctx context.Context, id string) (*types.KBSnapshot, error) {
+	snapshot, err := s.snapshotRepo.GetSnapshot(ctx, types.MustTenantIDFromContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot == nil {
+		return nil, werrors.NewNotFoundError("快照不存在")
+	}
+	return snapshot, nil
+}
+
+// DeleteKBSnapshot deletes a snapshot and its archive parts. Snapshots
+// still being written cannot be deleted.
+func (s *knowledgeService) DeleteKBSnapshot(ctx context.Context, id string) error {
+	snapshot, err := s.GetKBSnapshot(ctx, id)
+	if err != nil {
+		return err
+	}
+	if snapshot.Status == types.KBSnapshotStatusRunning {
+		return werrors.NewBadRequestError("快照正在生成，请稍后再删除")
+	}
+	return s.deleteKBSnapshot(ctx, snapshot)
+}
+
+func (s *knowledgeService) deleteKBSnapshot(ctx context.Context, snapshot *types.KBSnapshot) error {
+	s.deleteKBSnapshotParts(ctx, snapshot.Parts)
+	return s.snapshotRepo.DeleteSnapshot(ctx, snapshot.TenantID, snapshot.ID)
+}
+
+// deleteKBSnapshotParts removes archive parts; failures only leak storage
+// and are logged.
+func (s *knowledgeService) deleteKBSnapshotParts(ctx context.Context, parts []string) {
+	for _, path := range parts {
+		if err := s.fileSvc.DeleteFile(ctx, path); err != nil {
+			logger.Warnf(ctx, "Failed to delete KB snapshot part %s: %v", path, err)
+		}
+	}
+}
+
+// RestoreKBSnapshot restores a completed snapshot into a new knowledge base
+// of the current tenant. The knowledge base is created right away from the
+// snapshot's configuration; its content is restored by a kb:restore task
+// whose progress is reported like a knowledge base copy.
+func (s *knowledgeService) RestoreKBSnapshot(ctx context.Context, id, name string) (*types.KBCloneProgress, error) {
+	snapshot, err := s.GetKBSnapshot(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if snapshot.Status != types.KBSnapshotStatusCompleted {
+		return nil, werrors.NewBadRequestError("只能恢复已完成的快照")
+	}
+
+	var kb *types.KnowledgeBase
+	err = readKBSnapshot(snapshot.Parts, func(path string) (io.ReadCloser, error) {
+		return s.fileSvc.GetFile(ctx, path)
+	}, func(rec *types.KBSnapshotRecord) error {
+		switch rec.Kind {
+		case types.KBSnapshotKindManifest:
+			if rec.Manifest == nil || rec.Manifest.Version > types.KBSnapshotFormatVersion {
+				return werrors.NewBadRequestError("快照格式版本不受支持")
+			}
+			return nil
+		case types.KBSnapshotKindKnowledgeBase:
+			kb = rec.KnowledgeBase
+		}
+		return errKBSnapshotStop
+	})
+	if err != nil {
+		return nil, err
+	}
+	if kb == nil {
+		return nil, werrors.NewBadRequestError("快照缺少知识库配置")
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("%s (restored %s)", kb.Name, snapshot.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	kb.ID = ""
+	kb.Name = name
+	kb.CreatorID = ""
+	kb.IndexGeneration = 0
+	kb.PendingIndexGeneration = 0
+	kb.DeletedAt = gorm.DeletedAt{}
+	kb, err = s.kbService.CreateKnowledgeBase(ctx, kb)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to create knowledge base for snapshot restore: %v", err)
+		return nil, err
+	}
+
+	taskID := uuid.New().String()
+	now := time.Now().Unix()
+	progress := &types.KBCloneProgress{
+		TaskID:    taskID,
+		SourceID:  snapshot.ID,
+		TargetID:  kb.ID,
+		Status:    types.KBCloneStatusPending,
+		Total:     snapshot.KnowledgeCount + snapshot.ChunkCount + snapshot.VectorCount,
+		Message:   "Restore task queued",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.saveKBCloneProgress(ctx, progress); err != nil {
+		logger.Warnf(ctx, "Failed to save KB restore progress: %v", err)
+	}
+
+	payload := types.KBRestorePayload{
+		TenantID:   kb.TenantID,
+		TaskID:     taskID,
+		SnapshotID: snapshot.ID,
+		TargetID:   kb.ID,
+	}
+	langfuse.InjectTracing(ctx, &payload)
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal KB restore payload: %w", err)
+	}
+	// A restore is not idempotent, so it is never retried; a failed restore
+	// leaves a partial knowledge base for the caller to delete.
+	task := asynq.NewTask(types.TypeKBRestore, payloadBytes,
+		asynq.TaskID(taskID),
+		asynq.Queue(types.QueueLow),
+		asynq.MaxRetry(0),
+		asynq.Timeout(12*time.Hour),
+	)
+	if _, err := s.task.Enqueue(task); err != nil {
+		logger.Errorf(ctx, "Failed to enqueue KB restore task: %v", err)
+		return nil, err
+	}
+	logger.Infof(ctx, "KB restore %s enqueued: snapshot %s -> knowledge base %s", taskID, snapshot.ID, kb.ID)
+	return progress, nil
+}
+
+// GetKBSnapshotPolicy returns the snapshot schedule of a knowledge base; a
+// knowledge base without one reports a disabled default policy.
+func (s *knowledgeService) GetKBSnapshotPolicy(ctx context.Context, kbID string) (*types.KBSnapshotPolicy, error) {
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := s.snapshotRepo.GetPolicy(ctx, kb.TenantID, kb.ID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &types.KBSnapshotPolicy{KnowledgeBaseID: kb.ID, TenantID: kb.TenantID}
+		policy.Normalize()
+	}
+	return policy, nil
+}
+
+// UpdateKBSnapshotPolicy replaces the snapshot schedule of a knowledge base.
+// Enabling a schedule (or changing its interval) makes the first snapshot
+// due one interval from now.
+func (s *knowledgeService) UpdateKBSnapshotPolicy(ctx context.Context, kbID string,
+	req *types.KBSnapshotPolicy,
+) (*types.KBSnapshotPolicy, error) {
+	current, err := s.GetKBSnapshotPolicy(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Enabled {
+		kb, err := s.kbService.GetKnowledgeBaseByID(ctx, kbID)
+		if err != nil {
+			return nil, err
+		}
+		if kb.Type == types.KnowledgeBaseTypeWiki {
+			return nil, werrors.NewBadRequestError("Wiki 知识库暂不支持快照")
+		}
+	}
+	policy := &types.KBSnapshotPolicy{
+		KnowledgeBaseID: current.KnowledgeBaseID,
+		TenantID:        current.TenantID,
+		Enabled:         req.Enabled,
+		IntervalHours:   req.IntervalHours,
+		Retain:          req.Retain,
+		CreatedAt:       current.CreatedAt,
+		UpdatedAt:       time.Now(),
+	}
+	policy.Normalize()
+	if policy.Enabled {
+		next := current.NextRunAt
+		if !current.Enabled || next == nil || current.IntervalHours != policy.IntervalHours {
+			at := time.Now().Add(policy.Interval())
+			next = &at
+		}
+		policy.NextRunAt = next
+	}
+	if policy.CreatedAt.IsZero() {
+		policy.CreatedAt = policy.UpdatedAt
+	}
+	if err := s.snapshotRepo.SavePolicy(ctx, policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// ProcessKBSnapshot handles kb:snapshot tasks: it streams the knowledge
+// base into archive parts and records them on the snapshot. Parts of a
+// failed attempt are deleted so a retry starts from scratch.
+func (s *knowledgeService) ProcessKBSnapshot(ctx context.Context, t *asynq.Task) error {
+	var payload types.KBSnapshotPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "Failed to unmarshal KB snapshot payload: %v", err)
+		return nil
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get tenant %d: %v", payload.TenantID, err)
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+
+	snapshot, err := s.snapshotRepo.GetSnapshot(ctx, payload.TenantID, payload.SnapshotID)
+	if err != nil {
+		return err
+	}
+	if snapshot == nil || snapshot.Status == types.KBSnapshotStatusCompleted {
+		return nil
+	}
+	// Without an asynq retry count (lite mode) every failure is final.
+	_, inAsynq := asynq.GetRetryCount(ctx)
+	final := !inAsynq || isFinalAsynqAttempt(ctx)
+
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, snapshot.KnowledgeBaseID)
+	if err != nil {
+		if errors.Is(err, repository.ErrKnowledgeBaseNotFound) {
+			final = true
+		}
+		s.failKBSnapshot(ctx, snapshot, err, final)
+		if final {
+			return nil
+		}
+		return err
+	}
+
+	snapshot.Status = types.KBSnapshotStatusRunning
+	snapshot.ErrorMessage = ""
+	if err := s.snapshotRepo.UpdateSnapshot(ctx, snapshot); err != nil {
+		logger.Warnf(ctx, "Failed to mark KB snapshot %s running: %v", snapshot.ID, err)
+	}
+
+	if err := s.runKBSnapshot(ctx, kb, snapshot); err != nil {
+		logger.Errorf(ctx, "KB snapshot %s failed: %v", snapshot.ID, err)
+		s.failKBSnapshot(ctx, snapshot, err, final)
+		return err
+	}
+
+	completedAt := time.Now()
+	snapshot.Status = types.KBSnapshotStatusCompleted
+	snapshot.CompletedAt = &completedAt
+	if err := s.snapshotRepo.UpdateSnapshot(ctx, snapshot); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "KB snapshot %s completed: %d parts, %d bytes, %d knowledge, %d chunks, %d vectors",
+		snapshot.ID, len(snapshot.Parts), snapshot.Size, snapshot.KnowledgeCount, snapshot.ChunkCount, snapshot.VectorCount)
+
+	if snapshot.Trigger == types.KBSnapshotTriggerScheduled {
+		s.pruneKBSnapshots(ctx, kb)
+	}
+	return nil
+}
+
+// failKBSnapshot drops the parts written by a failed attempt. The snapshot
+// is marked failed on the final attempt and back to pending otherwise.
+func (s *knowledgeService) failKBSnapshot(ctx context.Context, snapshot *types.KBSnapshot, cause error, final bool) {
+	s.deleteKBSnapshotParts(ctx, snapshot.Parts)
+	snapshot.Parts = nil
+	snapshot.Size = 0
+	snapshot.KnowledgeCount, snapshot.ChunkCount, snapshot.VectorCount = 0, 0, 0
+	snapshot.ErrorMessage = cause.Error()
+	snapshot.Status = types.KBSnapshotStatusPending
+	if final {
+		snapshot.Status = types.KBSnapshotStatusFailed
+	}
+	if err := s.snapshotRepo.UpdateSnapshot(ctx, snapshot); err != nil {
+		logger.Warnf(ctx, "Failed to record KB snapshot %s failure: %v", snapshot.ID, err)
+	}
+}
+
+// runKBSnapshot writes the archive: manifest, knowledge base, tags, then
+// each completed knowledge followed by its chunks of the live index
+// generation, and finally the index rows of those chunks.
+func (s *knowledgeService) runKBSnapshot(ctx context.Context, kb *types.KnowledgeBase, snapshot *types.KBSnapshot) error {
+	tenantID := kb.TenantID
+	w := newKBSnapshotWriter(kbSnapshotPartSize, func(part int, data []byte) (string, error) {
+		name := fmt.Sprintf("kb-snapshot-%s-%04d.jsonl.gz", snapshot.ID, part)
+		return s.fileSvc.SaveBytes(ctx, data, tenantID, name, false)
+	})
+	defer func() {
+		snapshot.Parts = w.parts
+		snapshot.Size = w.size
+	}()
+
+	if err := w.write(&types.KBSnapshotRecord{
+		Kind: types.KBSnapshotKindManifest,
+		Manifest: &types.KBSnapshotManifest{
+			Version:          types.KBSnapshotFormatVersion,
+			SnapshotID:       snapshot.ID,
+			KnowledgeBaseID:  kb.ID,
+			EmbeddingModelID: kb.EmbeddingModelID,
+			CreatedAt:        snapshot.CreatedAt,
+		},
+	}); err != nil {
+		return err
+	}
+	if err := w.write(&types.KBSnapshotRecord{Kind: types.KBSnapshotKindKnowledgeBase, KnowledgeBase: kb}); err != nil {
+		return err
+	}
+
+	tags, _, err := s.tagRepo.ListByKB(ctx, tenantID, kb.ID, nil, "")
+	if err != nil {
+		return fmt.Errorf("failed to list tags: %w", err)
+	}
+	for _, tag := range tags {
+		if err := w.write(&types.KBSnapshotRecord{Kind: types.KBSnapshotKindTag, Tag: tag}); err != nil {
+			return err
+		}
+	}
+
+	knowledgeList, err := s.repo.ListKnowledgeByKnowledgeBaseID(ctx, tenantID, kb.ID)
+	if err != nil {
+		return fmt.Errorf("failed to list knowledge: %w", err)
+	}
+	chunkIDs := make(map[string]struct{})
+	snapshot.KnowledgeCount, snapshot.ChunkCount, snapshot.VectorCount = 0, 0, 0
+	for i := 0; i < len(knowledgeList); i += kbRestoreBatch {
+		batch := knowledgeList[i:min(i+kbRestoreBatch, len(knowledgeList))]
+		ids := make([]string, 0, len(batch))
+		for _, k := range batch {
+			ids = append(ids, k.ID)
+		}
+		knowledgeTags, err := s.repo.GetKnowledgeTags(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("failed to load knowledge tags: %w", err)
+		}
+		for _, k := range batch {
+			if k.ParseStatus != types.ParseStatusCompleted {
+				continue
+			}
+			rec := &types.KBSnapshotRecord{Kind: types.KBSnapshotKindKnowledge, Knowledge: k}
+			for _, tag := range knowledgeTags[k.ID] {
+				rec.KnowledgeTagIDs = append(rec.KnowledgeTagIDs, tag.ID)
+			}
+			k.Tags = nil
+			if err := w.write(rec); err != nil {
+				return err
+			}
+			snapshot.KnowledgeCount++
+
+			chunks, err := s.chunkRepo.ListChunksByKnowledgeID(ctx, tenantID, k.ID)
+			if err != nil {
+				return fmt.Errorf("failed to list chunks of %s: %w", k.ID, err)
+			}
+			for _, c := range chunks {
+				if c.IndexGeneration != kb.IndexGeneration {
+					continue
+				}
+				if err := w.write(&types.KBSnapshotRecord{Kind: types.KBSnapshotKindChunk, Chunk: c}); err != nil {
+					return err
+				}
+				chunkIDs[c.ID] = struct{}{}
+				snapshot.ChunkCount++
+			}
+		}
+	}
+
+	snapshot.VectorsIncluded = false
+	if kb.NeedsEmbeddingModel() && len(chunkIDs) > 0 {
+		engine, err := retriever.CreateRetrieveEngineForKB(ctx, s.retrieveEngine, s.ownership, tenantID, kb.VectorStoreID)
+		if err != nil {
+			return fmt.Errorf("failed to init retrieve engine: %w", err)
+		}
+		err = engine.ExportIndices(ctx, kb.ID, kbSnapshotExportBatch, func(rows []*types.ExportedIndex) error {
+			for _, row := range rows {
+				if _, ok := chunkIDs[row.ChunkID]; !ok {
+					continue
+				}
+				if err := w.write(&types.KBSnapshotRecord{Kind: types.KBSnapshotKindVector, Vector: row}); err != nil {
+					return err
+				}
+				snapshot.VectorCount++
+			}
+			return nil
+		})
+		switch {
+		case errors.Is(err, retriever.ErrExportUnsupported):
+			logger.Infof(ctx, "KB snapshot %s: engine cannot export vectors, restore will re-embed", snapshot.ID)
+		case err != nil:
+			return fmt.Errorf("failed to export index rows: %w", err)
+		default:
+			snapshot.VectorsIncluded = true
+		}
+	}
+	return w.flush()
+}
+
+// pruneKBSnapshots deletes the oldest completed scheduled snapshots of a
+// knowledge base beyond its policy's retention.
+func (s *knowledgeService) pruneKBSnapshots(ctx context.Context, kb *types.KnowledgeBase) {
+	policy, err := s.snapshotRepo.GetPolicy(ctx, kb.TenantID, kb.ID)
+	if err != nil || policy == nil {
+		return
+	}
+	policy.Normalize()
+	snapshots, err := s.snapshotRepo.ListSnapshots(ctx, kb.TenantID, kb.ID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to list KB snapshots for retention: %v", err)
+		return
+	}
+	kept := 0
+	for _, snapshot := range snapshots {
+		if snapshot.Trigger != types.KBSnapshotTriggerScheduled || snapshot.Status != types.KBSnapshotStatusCompleted {
+			continue
+		}
+		if kept < policy.Retain {
+			kept++
+			continue
+		}
+		if err := s.deleteKBSnapshot(ctx, snapshot); err != nil {
+			logger.Warnf(ctx, "Failed to prune KB snapshot %s: %v", snapshot.ID, err)
+			continue
+		}
+		logger.Infof(ctx, "Pruned KB snapshot %s of knowledge base %s", snapshot.ID, kb.ID)
+	}
+}
+
+// ProcessKBRestore handles kb:restore tasks.
+func (s *knowledgeService) ProcessKBRestore(ctx context.Context, t *asynq.Task) error {
+	var payload types.KBRestorePayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "Failed to unmarshal KB restore payload: %v", err)
+		return nil
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	tenantInfo, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get tenant %d: %v", payload.TenantID, err)
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenantInfo)
+
+	progress := &types.KBCloneProgress{
+		TaskID:    payload.TaskID,
+		SourceID:  payload.SnapshotID,
+		TargetID:  payload.TargetID,
+		Status:    types.KBCloneStatusProcessing,
+		Message:   "Restoring knowledge base snapshot...",
+		UpdatedAt: time.Now().Unix(),
+	}
+	if saved, err := s.GetKBCloneProgress(ctx, payload.TaskID); err == nil {
+		progress.Total = saved.Total
+		progress.CreatedAt = saved.CreatedAt
+	}
+	_ = s.saveKBCloneProgress(ctx, progress)
+
+	if err := s.runKBRestore(ctx, &payload, progress); err != nil {
+		logger.Errorf(ctx, "KB restore %s failed: %v", payload.TaskID, err)
+		progress.Status = types.KBCloneStatusFailed
+		progress.Error = err.Error()
+		progress.Message = "Knowledge base restore failed; delete the partially restored knowledge base and retry"
+		progress.UpdatedAt = time.Now().Unix()
+		_ = s.saveKBCloneProgress(ctx, progress)
+		return err
+	}
+
+	progress.Status = types.KBCloneStatusCompleted
+	progress.Progress = 100
+	progress.Processed = progress.Total
+	progress.Message = "Knowledge base restore completed successfully"
+	progress.UpdatedAt = time.Now().Unix()
+	_ = s.saveKBCloneProgress(ctx, progress)
+	logger.Infof(ctx, "KB restore %s completed", payload.TaskID)
+	return nil
+}
+
+// kbRestoreTarget holds the state of a running restore.
+type kbRestoreTarget struct {
+	kb         *types.KnowledgeBase
+	tenantID   uint64
+	ids        *types.KBSnapshotIDMap
+	engine     *retriever.CompositeRetrieveEngine // nil when the KB keeps no index
+	embedder   embedding.Embedder                 // nil unless rows must be re-embedded
+	reembed    bool
+	fileSvc    interfaces.FileService
+	urlCache   map[string]string
+	knowledge  map[string]*types.Knowledge // new ID -> restored knowledge
+	chunks     []*types.Chunk
+	rows       []*types.IndexInfo
+	vectors    map[string][]float32
+	storage    int64
+	processed  int
+	lastReport time.Time
+}
+
+// remapKBSnapshotChunk rewrites a chunk read from a snapshot into the
+// target knowledge base. Links to chunks and tags outside the archive are
+// dropped; relation lists are not restored.
+func remapKBSnapshotChunk(c *types.Chunk, ids *types.KBSnapshotIDMap, tenantID uint64, kbID string) {
+	c.ID = ids.Chunk(c.ID)
+	c.SeqID = 0
+	c.TenantID = tenantID
+	c.KnowledgeID = ids.Knowledge(c.KnowledgeID)
+	c.KnowledgeBaseID = kbID
+	c.TagID = mapKBSnapshotTag(ids, c.TagID)
+	c.PreChunkID = ids.Chunk(c.PreChunkID)
+	c.NextChunkID = ids.Chunk(c.NextChunkID)
+	c.ParentChunkID = ids.Chunk(c.ParentChunkID)
+	c.RelationChunks = nil
+	c.IndirectRelationChunks = nil
+	c.IndexGeneration = 0
+	c.DeletedAt = gorm.DeletedAt{}
+}
+
+func mapKBSnapshotTag(ids *types.KBSnapshotIDMap, old string) string {
+	if !ids.HasTag(old) {
+		return ""
+	}
+	return ids.Tag(old)
+}
+
+func (s *knowledgeService) runKBRestore(ctx context.Context, payload *types.KBRestorePayload,
+	progress *types.KBCloneProgress,
+) error {
+	snapshot, err := s.snapshotRepo.GetSnapshot(ctx, payload.TenantID, payload.SnapshotID)
+	if err != nil {
+		return err
+	}
+	if snapshot == nil {
+		return fmt.Errorf("snapshot %s not found", payload.SnapshotID)
+	}
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, payload.TargetID)
+	if err != nil {
+		return fmt.Errorf("failed to get target knowledge base: %w", err)
+	}
+
+	target := &kbRestoreTarget{
+		kb:        kb,
+		tenantID:  payload.TenantID,
+		ids:       types.NewKBSnapshotIDMap(),
+		reembed:   !snapshot.VectorsIncluded,
+		fileSvc:   s.resolveFileService(ctx, kb),
+		urlCache:  make(map[string]string),
+		knowledge: make(map[string]*types.Knowledge),
+		vectors:   make(map[string][]float32),
+	}
+	if kb.NeedsEmbeddingModel() {
+		target.engine, err = retriever.CreateRetrieveEngineForKB(ctx, s.retrieveEngine, s.ownership, payload.TenantID, kb.VectorStoreID)
+		if err != nil {
+			return fmt.Errorf("failed to init retrieve engine: %w", err)
+		}
+		target.embedder, err = s.modelService.GetEmbeddingModel(ctx, kb.EmbeddingModelID)
+		if err != nil && target.reembed {
+			return fmt.Errorf("failed to get embedding model: %w", err)
+		}
+	}
+
+	err = readKBSnapshot(snapshot.Parts, func(path string) (io.ReadCloser, error) {
+		return s.fileSvc.GetFile(ctx, path)
+	}, func(rec *types.KBSnapshotRecord) error {
+		var err error
+		switch rec.Kind {
+		case types.KBSnapshotKindTag:
+			err = s.restoreKBSnapshotTag(ctx, target, rec.Tag)
+		case types.KBSnapshotKindKnowledge:
+			if err = s.flushRestoredChunks(ctx, target); err == nil {
+				err = s.restoreKBSnapshotKnowledge(ctx, target, rec)
+			}
+		case types.KBSnapshotKindChunk:
+			err = s.restoreKBSnapshotChunk(ctx, target, rec.Chunk)
+		case types.KBSnapshotKindVector:
+			if err = s.flushRestoredChunks(ctx, target); err == nil {
+				err = s.restoreKBSnapshotVector(ctx, target, rec.Vector)
+			}
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target.processed++
+		if time.Since(target.lastReport) > 5*time.Second {
+			target.lastReport = time.Now()
+			progress.Processed = target.processed
+			if progress.Total > 0 {
+				progress.Progress = min(99, target.processed*100/progress.Total)
+			}
+			progress.UpdatedAt = time.Now().Unix()
+			_ = s.saveKBCloneProgress(ctx, progress)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := s.flushRestoredChunks(ctx, target); err != nil {
+		return err
+	}
+	if err := s.flushRestoredVectors(ctx, target); err != nil {
+		return err
+	}
+	if target.storage > 0 {
+		if err := s.tenantRepo.AdjustStorageUsed(ctx, target.tenantID, target.storage); err != nil {
+			logger.Warnf(ctx, "KB restore: failed to update tenant storage used: %v", err)
+		}
+	}
+	return nil
+}
+
+func (s *knowledgeService) restoreKBSnapshotTag(ctx context.Context, target *kbRestoreTarget, tag *types.KnowledgeTag) error {
+	if tag == nil {
+		return nil
+	}
+	tag.ID = target.ids.Tag(tag.ID)
+	if tag.ParentID != "" {
+		tag.ParentID = target.ids.Tag(tag.ParentID)
+	}
+	tag.SeqID = 0
+	tag.TenantID = target.tenantID
+	tag.KnowledgeBaseID = target.kb.ID
+	if err := s.tagRepo.Create(ctx, tag); err != nil {
+		return fmt.Errorf("failed to restore tag %s: %w", tag.Name, err)
+	}
+	return nil
+}
+
+// restoreKBSnapshotKnowledge recreates a knowledge under a new ID. Its
+// source file is copied when it still exists in storage; otherwise the
+// knowledge is restored without a file.
+func (s *knowledgeService) restoreKBSnapshotKnowledge(ctx context.Context, target *kbRestoreTarget,
+	rec *types.KBSnapshotRecord,
+) error {
+	k := rec.Knowledge
+	if k == nil {
+		return nil
+	}
+	k.ID = target.ids.Knowledge(k.ID)
+	k.TenantID = target.tenantID
+	k.KnowledgeBaseID = target.kb.ID
+	k.Tags = nil
+	k.PendingSubtasksCount = 0
+	k.PreviewPath = ""
+	k.DeletedAt = gorm.DeletedAt{}
+	if k.FilePath != "" {
+		newPath, err := target.fileSvc.CopyFile(ctx, k.FilePath, target.tenantID, k.ID)
+		if err != nil {
+			logger.Warnf(ctx, "KB restore: source file of %s unavailable, restoring without it: %v", k.ID, err)
+			k.FilePath = ""
+			k.EncryptionKeyID = ""
+		} else {
+			k.FilePath = newPath
+			k.EncryptionKeyID = fileEncryptionKeyID(target.fileSvc)
+		}
+	}
+	if err := s.repo.CreateKnowledge(ctx, k); err != nil {
+		return fmt.Errorf("failed to restore knowledge %s: %w", k.Title, err)
+	}
+	if len(rec.KnowledgeTagIDs) > 0 {
+		tagIDs := make([]string, 0, len(rec.KnowledgeTagIDs))
+		for _, id := range rec.KnowledgeTagIDs {
+			if mapped := mapKBSnapshotTag(target.ids, id); mapped != "" {
+				tagIDs = append(tagIDs, mapped)
+			}
+		}
+		if err := s.repo.SetKnowledgeTags(ctx, k.ID, tagIDs); err != nil {
+			return fmt.Errorf("failed to restore tags of knowledge %s: %w", k.Title, err)
+		}
+	}
+	target.storage += k.StorageSize
+	target.knowledge[k.ID] = &types.Knowledge{ID: k.ID, Title: k.Title}
+	return nil
+}
+
+func (s *knowledgeService) restoreKBSnapshotChunk(ctx context.Context, target *kbRestoreTarget, c *types.Chunk) error {
+	if c == nil {
+		return nil
+	}
+	remapKBSnapshotChunk(c, target.ids, target.tenantID, target.kb.ID)
+	if c.ImageInfo != "" {
+		imageInfo, _, err := cloneChunkImageInfo(ctx, target.fileSvc, c.ImageInfo, target.tenantID, c.KnowledgeID, target.urlCache)
+		if err != nil {
+			logger.Warnf(ctx, "KB restore: images of chunk %s unavailable: %v", c.ID, err)
+			imageInfo = ""
+		}
+		c.ImageInfo = imageInfo
+	}
+	target.chunks = append(target.chunks, c)
+	if len(target.chunks) >= kbRestoreBatch {
+		return s.flushRestoredChunks(ctx, target)
+	}
+	return nil
+}
+
+// flushRestoredChunks inserts the buffered chunks. When the snapshot holds
+// no vectors they are indexed (and embedded) the way ingestion does.
+func (s *knowledgeService) flushRestoredChunks(ctx context.Context, target *kbRestoreTarget) error {
+	if len(target.chunks) == 0 {
+		return nil
+	}
+	chunks := target.chunks
+	target.chunks = nil
+	if err := s.chunkRepo.CreateChunks(ctx, chunks); err != nil {
+		return fmt.Errorf("failed to restore chunks: %w", err)
+	}
+	if !target.reembed || target.engine == nil {
+		return nil
+	}
+
+	var infos []*types.IndexInfo
+	if target.kb.Type == types.KnowledgeBaseTypeFAQ {
+		for _, c := range chunks {
+			list, err := s.buildFAQIndexInfoList(ctx, target.kb, c)
+			if err != nil {
+				return fmt.Errorf("failed to build index of FAQ entry %s: %w", c.ID, err)
+			}
+			infos = append(infos, list...)
+		}
+	} else {
+		byKnowledge := make(map[string][]*types.Chunk)
+		for _, c := range chunks {
+			byKnowledge[c.KnowledgeID] = append(byKnowledge[c.KnowledgeID], c)
+		}
+		for id, group := range byKnowledge {
+			if k, ok := target.knowledge[id]; ok {
+				infos = append(infos, generationIndexInfo(k, group)...)
+			}
+		}
+	}
+	if len(infos) == 0 {
+		return nil
+	}
+	if err := target.engine.BatchIndex(ctx, target.embedder, infos); err != nil {
+		return fmt.Errorf("failed to index restored chunks: %w", err)
+	}
+	return nil
+}
+
+// restoreKBSnapshotVector buffers an exported index row under the new IDs.
+// Rows of chunks that were not restored are skipped.
+func (s *knowledgeService) restoreKBSnapshotVector(ctx context.Context, target *kbRestoreTarget,
+	row *types.ExportedIndex,
+) error {
+	if row == nil || target.engine == nil || !target.ids.HasChunk(row.ChunkID) ||
+		!target.ids.HasKnowledge(row.KnowledgeID) {
+		return nil
+	}
+	info := &types.IndexInfo{
+		SourceID:        target.ids.SourceID(row.SourceID, row.ChunkID),
+		SourceType:      row.SourceType,
+		ChunkID:         target.ids.Chunk(row.ChunkID),
+		KnowledgeID:     target.ids.Knowledge(row.KnowledgeID),
+		KnowledgeBaseID: target.kb.ID,
+		TagID:           mapKBSnapshotTag(target.ids, row.TagID),
+		Content:         row.Content,
+		IsEnabled:       row.IsEnabled,
+	}
+	target.rows = append(target.rows, info)
+	if len(row.Embedding) > 0 {
+		target.vectors[info.SourceID] = row.Embedding
+	}
+	if len(target.rows) >= kbRestoreBatch {
+		return s.flushRestoredVectors(ctx, target)
+	}
+	return nil
+}
+
+// flushRestoredVectors writes buffered index rows with their stored
+// vectors; rows exported without a vector are embedded again.
+func (s *knowledgeService) flushRestoredVectors(ctx context.Context, target *kbRestoreTarget) error {
+	if len(target.rows) == 0 {
+		return nil
+	}
+	var embedded, unembedded []*types.IndexInfo
+	for _, info := range target.rows {
+		if _, ok := target.vectors[info.SourceID]; ok {
+			embedded = append(embedded, info)
+		} else {
+			unembedded = append(unembedded, info)
+		}
+	}
+	vectors := target.vectors
+	target.rows = nil
+	target.vectors = make(map[string][]float32)
+
+	if len(embedded) > 0 {
+		if err := target.engine.BatchIndexEmbedded(ctx, target.kb.EmbeddingModelID, embedded, vectors); err != nil {
+			return fmt.Errorf("failed to restore index rows: %w", err)
+		}
+	}
+	if len(unembedded) > 0 {
+		if target.embedder == nil {
+			return fmt.Errorf("embedding model %s unavailable for %d rows without vectors",
+				target.kb.EmbeddingModelID, len(unembedded))
+		}
+		if err := target.engine.BatchIndex(ctx, target.embedder, unembedded); err != nil {
+			return fmt.Errorf("failed to index restored rows: %w", err)
+		}
+	}
+	return nil
+}
@@ -32,6 +32,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/application/repository"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -437,6 +438,7 @@ func (t *spanTracker) EndSpan(ctx context.Context, span *Span, output types.JSON
 	if err := t.repo.Upsert(ctx, row); err != nil {
 		logger.Warnf(ctx, "[SpanTracker] EndSpan failed span=%s: %v", span.SpanID, err)
 	}
+	observeStage(ctx, span, metrics.StatusSuccess, dur)
 	t.touchKnowledgeHeartbeat(ctx, span.KnowledgeID, span.Kind)
 }
 
@@ -474,6 +476,7 @@ func (t *spanTracker) FailSpan(ctx context.Context, span *Span, errorCode, error
 	if err := t.repo.Upsert(ctx, row); err != nil {
 		logger.Warnf(ctx, "[SpanTracker] FailSpan failed span=%s: %v", span.SpanID, err)
 	}
+	observeStage(ctx, span, metrics.StatusError, dur)
 	// Cascade: anything downstream of this span gets cancelled. The
 	// reason string is what the UI surfaces under each cancelled
 	// child's tooltip — keep it short and human.
@@ -687,6 +690,15 @@ func isMainPipelineStage(name string) bool {
 
 // durationSince computes elapsed ms preferring the in-process cache;
 // falls back to the *Span's StartedAt for cross-process callers.
+// observeStage reports a finished pipeline stage to Prometheus. Subspans
+// are left out; their time is part of their stage.
+func observeStage(ctx context.Context, span *Span, status string, durMs int64) {
+	if span.Kind != types.SpanKindStage {
+		return
+	}
+	metrics.ObserveIngestionStage(ctx, span.Name, status, time.Duration(durMs)*time.Millisecond)
+}
+
 func durationSince(t *spanTracker, span *Span, now time.Time) int64 {
 	if start, ok := t.takeStart(span.SpanID); ok {
 		return now.Sub(start).Milliseconds()
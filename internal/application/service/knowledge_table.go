@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/infrastructure/chunker"
+	"github.com/Tencent/WeKnora/internal/infrastructure/docparser"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// maxTableSummaries caps the tables of one document summarized by the
+	// model; summaries are generated while the document is processed.
+	maxTableSummaries = 20
+	// maxTableSummaryInputRunes truncates the table text sent to the model.
+	maxTableSummaryInputRunes = 4000
+
+	// tableSummaryPromptTemplate is the prompt template for summarizing a
+	// document table so that it can be retrieved by prose questions.
+	tableSummaryPromptTemplate = `Summarize the following table from the document "%s" in 2-4 sentences.
+
+%s
+
+Table:
+%s
+
+Describe what the table is about, what its columns and rows represent, and its most notable values or trends.
+Write plain prose without lists or Markdown, in the same language as the table content.`
+)
+
+// normalizeTables converts the HTML tables of markdown to Markdown tables
+// when table chunking is on. Text with OCR layout blocks is left untouched:
+// the block offsets point into it.
+func normalizeTables(cc types.ChunkingConfig, result *types.ReadResult) {
+	if !cc.TableChunking || cc.EnableParentChild || result == nil || len(result.LayoutBlocks) > 0 {
+		return
+	}
+	result.MarkdownContent = docparser.NormalizeHTMLTables(result.MarkdownContent)
+}
+
+// splitFlatChunks splits text into flat (non parent-child) chunks, keeping
+// the heading path recorded by the markdown strategy as metadata. With
+// table chunking on, every table becomes a chunk of its own carrying its
+// columns and row range as metadata.
+func splitFlatChunks(text string, cc types.ChunkingConfig, cfg chunker.SplitterConfig) []types.ParsedChunk {
+	var splitChunks []chunker.Chunk
+	if cc.TableChunking {
+		splitChunks = chunker.SplitWithTables(text, cfg)
+	} else {
+		splitChunks = chunker.Split(text, cfg)
+	}
+	chunks := make([]types.ParsedChunk, len(splitChunks))
+	for i, c := range splitChunks {
+		chunks[i] = types.ParsedChunk{
+			Content:       c.Content,
+			ContextHeader: c.ContextHeader,
+			Seq:           c.Seq,
+			Start:         c.Start,
+			End:           c.End,
+			Metadata:      splitChunkMetadata(c),
+		}
+	}
+	return chunks
+}
+
+// splitChunkMetadata returns the document metadata the chunker recorded for
+// c (heading path, table, code symbol), or nil when there is none.
+func splitChunkMetadata(c chunker.Chunk) *types.DocumentChunkMetadata {
+	if len(c.HeadingPath) == 0 && c.Table == nil && c.Code == nil {
+		return nil
+	}
+	meta := &types.DocumentChunkMetadata{HeadingPath: c.HeadingPath}
+	if c.Table != nil {
+		meta.Table = &types.TableChunkMetadata{
+			Index:    c.Table.Index,
+			Columns:  c.Table.Columns,
+			RowCount: c.Table.RowCount,
+			RowStart: c.Table.RowStart,
+			RowEnd:   c.Table.RowEnd,
+		}
+	}
+	if c.Code != nil {
+		meta.Code = &types.CodeChunkMetadata{
+			FilePath:   c.Code.Path,
+			Language:   c.Code.Language,
+			Symbol:     c.Code.Symbol,
+			SymbolKind: c.Code.Kind,
+			StartLine:  c.Code.StartLine,
+			EndLine:    c.Code.EndLine,
+		}
+	}
+	return meta
+}
+
+// summarizeTables asks the knowledge base's summary model for a summary of
+// each table and stores it in the metadata of the table's first chunk.
+// Failures are logged and leave the table without a summary.
+func (s *knowledgeService) summarizeTables(ctx context.Context,
+	kb *types.KnowledgeBase, knowledge *types.Knowledge, chunks []types.ParsedChunk,
+) {
+	if kb.SummaryModelID == "" {
+		logger.Warnf(ctx, "Table summary enabled but knowledge base %s has no summary model", kb.ID)
+		return
+	}
+	var targets []int
+	for i, c := range chunks {
+		if c.Metadata != nil && c.Metadata.Table != nil && c.Metadata.Table.RowStart <= 1 {
+			targets = append(targets, i)
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+	if len(targets) > maxTableSummaries {
+		logger.Infof(ctx, "Summarizing the first %d of %d tables of knowledge %s",
+			maxTableSummaries, len(targets), knowledge.ID)
+		targets = targets[:maxTableSummaries]
+	}
+
+	chatModel, err := s.modelService.GetChatModel(ctx, kb.SummaryModelID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get summary model for table summaries: %v", err)
+		return
+	}
+	summarized := 0
+	for _, i := range targets {
+		summary, err := summarizeTable(ctx, chatModel, knowledge.Title, chunks[i].ContextHeader, chunks[i].Content)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to summarize table %d of knowledge %s: %v",
+				chunks[i].Metadata.Table.Index, knowledge.ID, err)
+			continue
+		}
+		if summary != "" {
+			chunks[i].Metadata.Table.Summary = summary
+			summarized++
+		}
+	}
+	logger.Infof(ctx, "Summarized %d/%d tables of knowledge %s", summarized, len(targets), knowledge.ID)
+}
+
+func summarizeTable(ctx context.Context, chatModel chat.Chat, docName, heading, table string) (string, error) {
+	if runes := []rune(table); len(runes) > maxTableSummaryInputRunes {
+		table = string(runes[:maxTableSummaryInputRunes]) + "\n..."
+	}
+	section := ""
+	if heading != "" {
+		section = "Section: " + heading
+	}
+	thinking := false
+	response, err := chatModel.Chat(ctx, []chat.Message{{
+		Role:    "user",
+		Content: fmt.Sprintf(tableSummaryPromptTemplate, docName, section, table),
+	}}, &chat.ChatOptions{
+		Temperature: 0.3,
+		MaxTokens:   300,
+		Thinking:    &thinking,
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(response.Content), nil
+}
+
+// tableSummarySourceID is the index source ID of a table chunk's summary.
+func tableSummarySourceID(chunkID string) string {
+	return chunkID + "-table-summary"
+}
+
+// tableSummaryIndexInfo returns the index entry of the table summary of c,
+// or nil when c has none. A hit on it resolves to the table chunk itself.
+func tableSummaryIndexInfo(c *types.Chunk) *types.IndexInfo {
+	meta, err := c.DocumentMetadata()
+	if err != nil || meta == nil || meta.Table == nil || meta.Table.Summary == "" {
+		return nil
+	}
+	return &types.IndexInfo{
+		Content:         meta.Table.Summary,
+		SourceID:        tableSummarySourceID(c.ID),
+		SourceType:      types.ChunkSourceType,
+		ChunkID:         c.ID,
+		KnowledgeID:     c.KnowledgeID,
+		KnowledgeBaseID: c.KnowledgeBaseID,
+		IsEnabled:       c.IsEnabled,
+	}
+}
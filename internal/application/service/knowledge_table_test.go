@@ -0,0 +1,70 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/infrastructure/chunker"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitFlatChunks_TableChunking(t *testing.T) {
+	text := "# 报价\n\n以下为各型号报价。\n\n| 型号 | 价格 |\n| --- | --- |\n| A1 | 100 |\n| B2 | 200 |\n\n以上价格含税。"
+	cfg := chunker.SplitterConfig{ChunkSize: 500, ChunkOverlap: 0}
+
+	plain := splitFlatChunks(text, types.ChunkingConfig{}, cfg)
+	require.Len(t, plain, 1)
+	assert.Nil(t, plain[0].Metadata)
+
+	chunks := splitFlatChunks(text, types.ChunkingConfig{TableChunking: true}, cfg)
+	require.Len(t, chunks, 3)
+	table := chunks[1].Metadata.Table
+	require.NotNil(t, table)
+	assert.Equal(t, []string{"型号", "价格"}, table.Columns)
+	assert.Equal(t, 2, table.RowCount)
+	assert.Equal(t, 1, table.RowStart)
+	assert.Equal(t, 2, table.RowEnd)
+	assert.True(t, strings.HasPrefix(chunks[1].Content, "| 型号 |"))
+	assert.Nil(t, chunks[0].Metadata)
+	assert.Nil(t, chunks[2].Metadata)
+}
+
+func TestTableSummaryIndexInfo(t *testing.T) {
+	chunk := &types.Chunk{ID: "c1", KnowledgeID: "k1", KnowledgeBaseID: "kb1", IsEnabled: true}
+	assert.Nil(t, tableSummaryIndexInfo(chunk))
+
+	require.NoError(t, chunk.SetDocumentMetadata(&types.DocumentChunkMetadata{
+		Table: &types.TableChunkMetadata{Columns: []string{"a"}, RowCount: 1, Summary: "各型号的含税报价"},
+	}))
+	info := tableSummaryIndexInfo(chunk)
+	require.NotNil(t, info)
+	assert.Equal(t, "各型号的含税报价", info.Content)
+	assert.Equal(t, "c1-table-summary", info.SourceID)
+	assert.Equal(t, "c1", info.ChunkID)
+	assert.Equal(t, "kb1", info.KnowledgeBaseID)
+}
+
+func TestChunkMetadataWithQuestions_KeepsTable(t *testing.T) {
+	chunk := &types.Chunk{ID: "c1"}
+	require.NoError(t, chunk.SetDocumentMetadata(&types.DocumentChunkMetadata{
+		Table: &types.TableChunkMetadata{RowCount: 3, Summary: "s"},
+	}))
+
+	meta := chunkMetadataWithQuestions(chunk, []types.GeneratedQuestion{{ID: "q1", Question: "价格是多少？"}})
+	require.NotNil(t, meta.Table)
+	assert.Equal(t, "s", meta.Table.Summary)
+	assert.Len(t, meta.GeneratedQuestions, 1)
+}
+
+func TestSplitFlatChunks_MarkdownHeadingPath(t *testing.T) {
+	text := "# Guide\n\n## Install\n\nRun the installer."
+	cfg := chunker.SplitterConfig{ChunkSize: 500, ChunkOverlap: 0, Strategy: chunker.StrategyMarkdown}
+
+	chunks := splitFlatChunks(text, types.ChunkingConfig{}, cfg)
+	require.Len(t, chunks, 1)
+	require.NotNil(t, chunks[0].Metadata)
+	assert.Equal(t, []string{"Guide", "Install"}, chunks[0].Metadata.HeadingPath)
+	assert.Equal(t, "Guide > Install", chunks[0].ContextHeader)
+}
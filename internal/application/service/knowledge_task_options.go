@@ -1,13 +1,19 @@
 package service
 
 import (
+	"context"
+
 	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/hibiken/asynq"
 )
 
-func documentProcessTaskOptions(cfg *config.Config, extra ...asynq.Option) []asynq.Option {
+// documentProcessTaskOptions returns the options for a document:process
+// task. The queue follows the ingest priority carried by ctx: interactive
+// work goes to the default queue, bulk work to the bulk lane.
+func documentProcessTaskOptions(ctx context.Context, cfg *config.Config, extra ...asynq.Option) []asynq.Option {
 	opts := []asynq.Option{
-		asynq.Queue("default"),
+		asynq.Queue(types.IngestPriorityFromContext(ctx).Queue()),
 		asynq.Timeout(config.DocumentProcessTimeout(cfg)),
 	}
 	opts = append(opts, extra...)
@@ -1,10 +1,12 @@
 package service
 
 import (
+	"context"
 	"testing"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/hibiken/asynq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -44,7 +46,7 @@ func TestDocumentProcessTaskOptions_defaults(t *testing.T) {
 	for _, tc := range cases {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
-			opts := documentProcessTaskOptions(tc.cfg)
+			opts := documentProcessTaskOptions(context.Background(), tc.cfg)
 			queue, timeout, maxRetry := parseDocumentProcessOpts(t, opts)
 			assert.Equal(t, "default", queue)
 			assert.Equal(t, config.DefaultDocumentProcessTimeout, timeout)
@@ -60,7 +62,7 @@ func TestDocumentProcessTaskOptions_configuredTimeout(t *testing.T) {
 			DocumentProcessTimeout: 90 * time.Minute,
 		},
 	}
-	opts := documentProcessTaskOptions(cfg)
+	opts := documentProcessTaskOptions(context.Background(), cfg)
 	queue, timeout, maxRetry := parseDocumentProcessOpts(t, opts)
 	assert.Equal(t, "default", queue)
 	assert.Equal(t, 90*time.Minute, timeout)
@@ -69,10 +71,20 @@ func TestDocumentProcessTaskOptions_configuredTimeout(t *testing.T) {
 
 func TestDocumentProcessTaskOptions_extraMaxRetry(t *testing.T) {
 	t.Parallel()
-	opts := documentProcessTaskOptions(nil, asynq.MaxRetry(3))
+	opts := documentProcessTaskOptions(context.Background(), nil, asynq.MaxRetry(3))
 	queue, timeout, maxRetry := parseDocumentProcessOpts(t, opts)
 	assert.Equal(t, "default", queue)
 	assert.Equal(t, config.DefaultDocumentProcessTimeout, timeout)
 	require.NotNil(t, maxRetry)
 	assert.Equal(t, 3, *maxRetry)
 }
+
+func TestDocumentProcessTaskOptions_bulkPriority(t *testing.T) {
+	t.Parallel()
+	ctx := types.WithIngestPriority(context.Background(), types.IngestPriorityBulk)
+	opts := documentProcessTaskOptions(ctx, nil, asynq.MaxRetry(3))
+	queue, _, maxRetry := parseDocumentProcessOpts(t, opts)
+	assert.Equal(t, types.QueueBulk, queue)
+	require.NotNil(t, maxRetry)
+	assert.Equal(t, 3, *maxRetry)
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/asr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranscriptToChunks(t *testing.T) {
+	text, chunks := transcriptToChunks([]asr.TranscriptChunk{
+		{Start: 0, End: 12.5, Speaker: "A", Text: "你好，欢迎参加会议。"},
+		{Start: 12.5, End: 70, Speaker: "B", Text: "谢谢。"},
+	}, "你好，欢迎参加会议。谢谢。")
+
+	require.Len(t, chunks, 2)
+	runes := []rune(text)
+	for i, c := range chunks {
+		assert.Equal(t, i, c.Seq)
+		assert.Equal(t, c.Content, string(runes[c.Start:c.End]))
+	}
+	assert.Equal(t, "[00:00 - 00:12] A: 你好，欢迎参加会议。", chunks[0].Content)
+	assert.Equal(t, 12.5, *chunks[1].Metadata.StartTime)
+	assert.Equal(t, 70.0, *chunks[1].Metadata.EndTime)
+	assert.Equal(t, "B", chunks[1].Metadata.Speaker)
+}
+
+func TestTranscriptToChunks_NoSegments(t *testing.T) {
+	text, chunks := transcriptToChunks(nil, "plain transcript")
+	assert.Equal(t, "plain transcript", text)
+	assert.Nil(t, chunks)
+}
+
+func TestIsTranscribableType(t *testing.T) {
+	assert.True(t, IsTranscribableType("MP3"))
+	assert.True(t, IsTranscribableType("mp4"))
+	assert.True(t, IsTranscribableType("webm"))
+	assert.False(t, IsTranscribableType("mkv"))
+	assert.False(t, IsTranscribableType("pdf"))
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	filesvc "github.com/Tencent/WeKnora/internal/application/service/file"
+	"github.com/Tencent/WeKnora/internal/infrastructure/docparser"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -22,10 +23,10 @@ import (
 func isValidFileType(filename string) bool {
 	switch strings.ToLower(getFileType(filename)) {
 	case "pdf", "txt", "docx", "doc", "epub", "mhtml", "md", "markdown", "png", "jpg", "jpeg", "gif", "csv", "xlsx", "xls", "pptx", "ppt", "json",
-		"mp3", "wav", "m4a", "flac", "ogg":
+		"mp3", "wav", "m4a", "flac", "ogg", "mp4", "webm":
 		return true
 	default:
-		return false
+		return docparser.CodeLanguage(getFileType(filename)) != ""
 	}
 }
 
@@ -119,7 +120,7 @@ func (s *knowledgeService) buildStorageConfig(ctx context.Context, kb *types.Kno
 	}
 
 	// Backward compatibility: if legacy cos_config has full params for the chosen provider, use them.
-	// Note: legacy StorageConfig predates tos/s3/oss/ks3, so those providers always
+	// Note: legacy StorageConfig predates tos/s3/oss/ks3/gcs, so those providers always
 	// resolve via the tenant-merge path below. Listing them here keeps the fall-through
 	// intentional (instead of an unrecognised provider silently sliding past the switch).
 	// See issue #1117: provider enum was missing tos/s3/oss in this switch.
@@ -130,7 +131,7 @@ func (s *knowledgeService) buildStorageConfig(ctx context.Context, kb *types.Kno
 		hasKBFull = sc.SecretID != "" && sc.BucketName != ""
 	case "minio":
 		hasKBFull = sc.BucketName != ""
-	case "local", "tos", "s3", "oss", "ks3":
+	case "local", "tos", "s3", "oss", "ks3", "gcs":
 		hasKBFull = false
 	}
 
@@ -227,6 +228,12 @@ func (s *knowledgeService) buildStorageConfig(ctx context.Context, kb *types.Kno
 				out.BucketName = sec.KS3.BucketName
 				out.PathPrefix = sec.KS3.PathPrefix
 			}
+		case "gcs":
+			if sec.GCS != nil {
+				out.Region = sec.GCS.Location
+				out.BucketName = sec.GCS.BucketName
+				out.PathPrefix = sec.GCS.PathPrefix
+			}
 		}
 	}
 
@@ -302,6 +309,15 @@ func (s *knowledgeService) resolveFileServiceForPath(ctx context.Context, kb *ty
 	return svc
 }
 
+// fileEncryptionKeyID returns the server-side encryption key svc applies to
+// new objects, or "" when it stores them unencrypted.
+func fileEncryptionKeyID(svc interfaces.FileService) string {
+	if enc, ok := filesvc.As[interfaces.EncryptedFileService](svc); ok {
+		return enc.EncryptionKeyID()
+	}
+	return ""
+}
+
 func IsImageType(fileType string) bool {
 	switch fileType {
 	case "jpg", "jpeg", "png", "gif", "webp", "bmp", "svg", "tiff":
@@ -321,6 +337,18 @@ func IsAudioType(fileType string) bool {
 	}
 }
 
+// IsTranscribableType checks if a file type is transcribed by the ASR model:
+// audio formats and the video formats (mp4, webm) whose audio track
+// Whisper-compatible endpoints accept directly
+func IsTranscribableType(fileType string) bool {
+	switch strings.ToLower(fileType) {
+	case "mp4", "webm":
+		return true
+	default:
+		return IsAudioType(fileType)
+	}
+}
+
 // IsVideoType checks if a file type is a video format
 func IsVideoType(fileType string) bool {
 	switch strings.ToLower(fileType) {
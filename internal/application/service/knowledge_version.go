@@ -0,0 +1,426 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"slices"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/infrastructure/filescan"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+)
+
+// UpdateKnowledgeFile replaces the file of a file knowledge with a new
+// version. The knowledge keeps its ID, tags and metadata, and stays
+// searchable with the previous version until the new one is indexed. The
+// new version is split as usual, but only the chunks whose content changed
+// are embedded: unchanged chunks keep their ID, vectors and generated
+// questions (see processChunks).
+//
+// Uploading the current file again is a no-op.
+func (s *knowledgeService) UpdateKnowledgeFile(ctx context.Context,
+	knowledgeID string, file *multipart.FileHeader, customFileName string, metadata map[string]string,
+) (*types.Knowledge, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	existing, err := s.repo.GetKnowledgeByID(ctx, tenantID, knowledgeID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to load knowledge: %v", err)
+		return nil, err
+	}
+	if existing.Type != "file" || existing.FilePath == "" {
+		return nil, werrors.NewBadRequestError("只有文件类型的知识可以更新文件")
+	}
+	switch existing.ParseStatus {
+	case types.ParseStatusQuarantined:
+		return nil, werrors.NewBadRequestError("文件未通过安全扫描，已隔离的文档不能更新")
+	case types.ParseStatusPending, types.ParseStatusProcessing,
+		types.ParseStatusFinalizing, types.ParseStatusDeleting:
+		return nil, werrors.NewConflictError("文档正在处理中，请稍后再更新")
+	}
+
+	fileName := file.Filename
+	if customFileName != "" {
+		fileName = customFileName
+	}
+	if IsVideoType(getFileType(fileName)) && !IsTranscribableType(getFileType(fileName)) {
+		return nil, werrors.NewBadRequestError("暂不支持上传视频文件")
+	}
+	if !isValidFileType(fileName) {
+		return nil, ErrInvalidFileType
+	}
+	safeFilename, isValid := secutils.ValidateInput(fileName)
+	if !isValid {
+		return nil, werrors.NewValidationError("文件名包含非法字符")
+	}
+
+	hash, err := calculateFileHash(file)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to calculate file hash: %v", err)
+		return nil, err
+	}
+	if hash == existing.FileHash && file.Size == existing.FileSize && safeFilename == existing.FileName {
+		logger.Infof(ctx, "File of knowledge %s is unchanged, skipping update", existing.ID)
+		return existing, nil
+	}
+
+	tenantInfo := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
+	if tenantInfo.StorageQuota > 0 && tenantInfo.StorageUsed >= tenantInfo.StorageQuota {
+		return nil, types.NewStorageQuotaExceededError()
+	}
+
+	kb, err := s.kbService.GetKnowledgeBaseByID(ctx, existing.KnowledgeBaseID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to get knowledge base: %v", err)
+		return nil, err
+	}
+
+	// Infected versions are rejected whatever the tenant's scan action: the
+	// previous version stays in place.
+	scan, err := s.scanUpload(ctx, kb.ID, file, safeFilename)
+	if err != nil {
+		return nil, err
+	}
+	if scan != nil && scan.quarantined {
+		scan.audit["action"] = filescan.ActionReject
+		s.recordUploadScan(ctx, scan, existing.ID)
+		return nil, werrors.NewBadRequestError("文件未通过安全扫描，已拒绝上传: " + scan.signature)
+	}
+
+	version, err := s.nextKnowledgeVersion(ctx, existing)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to resolve knowledge version: %v", err)
+		return nil, err
+	}
+
+	fileSvc := s.resolveFileService(ctx, kb)
+	filePath, err := fileSvc.SaveFile(ctx, file, tenantID, existing.ID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to save file, knowledge ID: %s, error: %v", existing.ID, err)
+		return nil, err
+	}
+
+	version.FileName = safeFilename
+	version.FileType = getFileType(safeFilename)
+	version.FileSize = file.Size
+	version.FileHash = hash
+	if s.versionRepo != nil {
+		if err := s.versionRepo.CreateVersion(ctx, version); err != nil {
+			logger.Errorf(ctx, "Failed to create knowledge version: %v", err)
+			if deleteErr := fileSvc.DeleteFile(ctx, filePath); deleteErr != nil {
+				logger.Errorf(ctx, "Failed to delete saved file, path: %s, error: %v", filePath, deleteErr)
+			}
+			return nil, err
+		}
+	}
+
+	// The ACL key is reserved; ACLs are set through UpdateKnowledgeACL
+	delete(metadata, types.KnowledgeACLMetadataKey)
+	if len(metadata) > 0 {
+		merged, err := existing.Metadata.Map()
+		if err != nil {
+			logger.Warnf(ctx, "Failed to read metadata of knowledge %s, replacing it: %v", existing.ID, err)
+			merged = make(map[string]interface{})
+		}
+		for k, v := range metadata {
+			merged[k] = v
+		}
+		metadataBytes, err := json.Marshal(merged)
+		if err != nil {
+			return nil, err
+		}
+		existing.Metadata = types.JSON(metadataBytes)
+	}
+
+	oldFilePath := existing.FilePath
+	oldFileSvc := s.resolveFileServiceForPath(ctx, kb, oldFilePath)
+	oldKnowledge := *existing
+
+	if existing.Title == existing.FileName {
+		existing.Title = safeFilename
+	}
+	existing.FileName = safeFilename
+	existing.FileType = getFileType(safeFilename)
+	existing.FileSize = file.Size
+	existing.FileHash = hash
+	existing.FilePath = filePath
+	existing.EncryptionKeyID = fileEncryptionKeyID(fileSvc)
+	existing.PreviewPath = ""
+	existing.EmbeddingModelID = kb.EmbeddingModelID
+	existing.ParseStatus = types.ParseStatusPending
+	existing.ErrorMessage = ""
+	existing.Description = ""
+	existing.UpdatedAt = time.Now()
+	// The previous version stays enabled and searchable while the new one
+	// is parsed. See ReparseKnowledge for why the counter is written apart.
+	existing.PendingSubtasksCount = 0
+	if err := s.repo.UpdateKnowledge(ctx, existing); err != nil {
+		logger.Errorf(ctx, "Failed to update knowledge file: %v", err)
+		return nil, err
+	}
+	if err := s.repo.UpdateKnowledgeColumn(ctx, existing.ID, "pending_subtasks_count", 0); err != nil {
+		logger.Errorf(ctx, "Failed to reset pending_subtasks_count: %v", err)
+		return nil, err
+	}
+	s.recordUploadScan(ctx, scan, existing.ID)
+
+	if oldFilePath != filePath {
+		if err := oldFileSvc.DeleteFile(ctx, oldFilePath); err != nil {
+			logger.Warnf(ctx, "Failed to delete previous file %s: %v", oldFilePath, err)
+		}
+	}
+	deleteKnowledgePreview(ctx, oldFileSvc, &oldKnowledge)
+	s.enqueueFilePreview(ctx, existing)
+
+	attempt := 0
+	if root, n, err := s.tracker().OpenAttempt(ctx, existing.ID, ""); err == nil && root != nil {
+		attempt = n
+	} else if err != nil {
+		logger.Warnf(ctx, "OpenAttempt failed for %s: %v (will fall back in worker)", existing.ID, err)
+	}
+	if kb.IsWikiEnabled() {
+		s.prepareWikiForReparse(ctx, existing)
+	}
+
+	processOverrides, _ := existing.ProcessOverrides()
+	eff := ResolveProcessConfig(kb, processOverrides)
+	questionCount := eff.QuestionGenerationConfig.QuestionCount
+	if questionCount <= 0 {
+		questionCount = 3
+	}
+	lang, _ := types.LanguageFromContext(ctx)
+	taskPayload := types.DocumentProcessPayload{
+		TenantID:                 tenantID,
+		KnowledgeID:              existing.ID,
+		KnowledgeBaseID:          existing.KnowledgeBaseID,
+		FilePath:                 filePath,
+		FileName:                 safeFilename,
+		FileType:                 existing.FileType,
+		EnableMultimodel:         eff.EnableMultimodel,
+		EnableQuestionGeneration: eff.QuestionGenerationConfig.Enabled,
+		QuestionCount:            questionCount,
+		Language:                 lang,
+		Attempt:                  attempt,
+		VersionID:                version.ID,
+	}
+	langfuse.InjectTracing(ctx, &taskPayload)
+	payloadBytes, err := json.Marshal(taskPayload)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to marshal document process task payload: %v", err)
+		return existing, nil
+	}
+	task := asynq.NewTask(
+		types.TypeDocumentProcess,
+		payloadBytes,
+		documentProcessTaskOptions(ctx, s.config, asynq.MaxRetry(3))...,
+	)
+	info, err := s.task.Enqueue(task)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to enqueue document process task: %v", err)
+		return existing, nil
+	}
+	logger.Infof(ctx, "Enqueued version %d of knowledge %s: task id=%s queue=%s",
+		version.Version, existing.ID, info.ID, info.Queue)
+
+	if slices.Contains([]string{"csv", "xlsx", "xls"}, existing.FileType) {
+		NewDataTableSummaryTask(ctx, s.task, tenantID, existing.ID, kb.SummaryModelID, kb.EmbeddingModelID)
+	}
+	return existing, nil
+}
+
+// nextKnowledgeVersion returns the version to record for a new file of
+// knowledge. Knowledge created before versions were recorded gets version 1
+// for its current file first.
+func (s *knowledgeService) nextKnowledgeVersion(
+	ctx context.Context, knowledge *types.Knowledge,
+) (*types.KnowledgeVersion, error) {
+	next := &types.KnowledgeVersion{
+		ID:          uuid.New().String(),
+		TenantID:    knowledge.TenantID,
+		KnowledgeID: knowledge.ID,
+		Version:     2,
+		CreatedAt:   time.Now(),
+	}
+	if s.versionRepo == nil {
+		return next, nil
+	}
+	latest, err := s.versionRepo.GetLatestVersion(ctx, knowledge.TenantID, knowledge.ID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil {
+		next.Version = latest.Version + 1
+		return next, nil
+	}
+	first := &types.KnowledgeVersion{
+		ID:          uuid.New().String(),
+		TenantID:    knowledge.TenantID,
+		KnowledgeID: knowledge.ID,
+		Version:     1,
+		FileName:    knowledge.FileName,
+		FileType:    knowledge.FileType,
+		FileSize:    knowledge.FileSize,
+		FileHash:    knowledge.FileHash,
+		CreatedAt:   knowledge.CreatedAt,
+	}
+	if err := s.versionRepo.CreateVersion(ctx, first); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// ListKnowledgeVersions returns the file versions of a knowledge, newest
+// first. Knowledge whose file was never updated has no versions.
+func (s *knowledgeService) ListKnowledgeVersions(
+	ctx context.Context, knowledgeID string,
+) ([]*types.KnowledgeVersion, error) {
+	tenantID := ctx.Value(types.TenantIDContextKey).(uint64)
+	if _, err := s.repo.GetKnowledgeByID(ctx, tenantID, knowledgeID); err != nil {
+		return nil, err
+	}
+	if s.versionRepo == nil {
+		return []*types.KnowledgeVersion{}, nil
+	}
+	return s.versionRepo.ListVersions(ctx, tenantID, knowledgeID)
+}
+
+// recordVersionChunkStats stores the chunk diff of an indexed version.
+func (s *knowledgeService) recordVersionChunkStats(ctx context.Context,
+	knowledge *types.Knowledge, versionID string, added, reused, removed int,
+) {
+	if s.versionRepo == nil {
+		return
+	}
+	if err := s.versionRepo.UpdateChunkStats(ctx, knowledge.TenantID, versionID, added, reused, removed); err != nil {
+		logger.Warnf(ctx, "Failed to record chunk stats of version %s: %v", versionID, err)
+	}
+}
+
+// versionChunkDiff compares the chunks of a new document version with the
+// chunks of the previous one.
+type versionChunkDiff struct {
+	// reused are the previous text chunks kept for the new version, with
+	// their position updated.
+	reused []*types.Chunk
+	// removed are the previous chunks of any type not kept.
+	removed   []*types.Chunk
+	reusedIDs map[string]bool
+}
+
+// diffVersionChunks matches the text chunks of a new version against the
+// previous chunks by content hash. A matching previous chunk replaces the new
+// one, keeping its ID, metadata and flags and taking the new position; each
+// previous chunk is matched at most once, in document order. It returns the
+// text chunks of the new version with the replacements made.
+func diffVersionChunks(previous, textChunks []*types.Chunk) ([]*types.Chunk, versionChunkDiff) {
+	diff := versionChunkDiff{reusedIDs: make(map[string]bool)}
+	byHash := make(map[string][]*types.Chunk)
+	for _, c := range previous {
+		if c.ChunkType == types.ChunkTypeText && c.ContentHash != "" {
+			byHash[c.ContentHash] = append(byHash[c.ContentHash], c)
+		}
+	}
+	for _, queue := range byHash {
+		slices.SortFunc(queue, func(a, b *types.Chunk) int { return a.ChunkIndex - b.ChunkIndex })
+	}
+
+	out := make([]*types.Chunk, len(textChunks))
+	for i, c := range textChunks {
+		queue := byHash[c.ContentHash]
+		if len(queue) == 0 {
+			out[i] = c
+			continue
+		}
+		old := queue[0]
+		byHash[c.ContentHash] = queue[1:]
+		old.ChunkIndex = c.ChunkIndex
+		old.StartAt = c.StartAt
+		old.EndAt = c.EndAt
+		old.ContextHeader = c.ContextHeader
+		old.PreChunkID = ""
+		old.NextChunkID = ""
+		old.UpdatedAt = c.UpdatedAt
+		out[i] = old
+		diff.reused = append(diff.reused, old)
+		diff.reusedIDs[old.ID] = true
+	}
+	for _, c := range previous {
+		if !diff.reusedIDs[c.ID] {
+			diff.removed = append(diff.removed, c)
+		}
+	}
+	return out, diff
+}
+
+// isReused reports whether the chunk was kept from the previous version.
+func (d versionChunkDiff) isReused(id string) bool {
+	return d.reusedIDs[id]
+}
+
+// created returns the chunks of the new version that must be created.
+func (d versionChunkDiff) created(textChunks []*types.Chunk) []*types.Chunk {
+	out := make([]*types.Chunk, 0, len(textChunks)-len(d.reused))
+	for _, c := range textChunks {
+		if !d.reusedIDs[c.ID] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// removedTextCount returns the number of previous text chunks not kept.
+func (d versionChunkDiff) removedTextCount() int {
+	return countTextChunks(d.removed)
+}
+
+func countTextChunks(chunks []*types.Chunk) int {
+	n := 0
+	for _, c := range chunks {
+		if c.ChunkType == types.ChunkTypeText {
+			n++
+		}
+	}
+	return n
+}
+
+// applyVersionChunkDiff persists the diff of a new version: the positions of
+// the kept chunks, and the removal of the other previous chunks with their
+// vectors (including the vectors of their generated questions) and extracted
+// images.
+func (s *knowledgeService) applyVersionChunkDiff(ctx context.Context,
+	kb *types.KnowledgeBase, knowledge *types.Knowledge, diff versionChunkDiff,
+	retrieveEngine *retriever.CompositeRetrieveEngine, embeddingModel embedding.Embedder,
+) error {
+	if err := s.chunkRepo.UpdateChunkPositions(ctx, knowledge.TenantID, diff.reused); err != nil {
+		return err
+	}
+	if len(diff.removed) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(diff.removed))
+	var imageInfos []string
+	for _, c := range diff.removed {
+		ids = append(ids, c.ID)
+		if c.ImageInfo != "" {
+			imageInfos = append(imageInfos, c.ImageInfo)
+		}
+	}
+	if retrieveEngine != nil && embeddingModel != nil {
+		if err := retrieveEngine.DeleteByChunkIDList(ctx, ids, embeddingModel.GetDimensions(), knowledge.Type); err != nil {
+			return err
+		}
+	}
+	if err := s.chunkRepo.DeleteChunks(ctx, knowledge.TenantID, ids); err != nil {
+		return err
+	}
+	deleteExtractedImages(ctx, s.resolveFileService(ctx, kb), collectImageURLs(ctx, imageInfos))
+	return nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func versionTextChunk(id string, index int, content string) *types.Chunk {
+	c := &types.Chunk{ID: id, ChunkIndex: index, StartAt: index * 100, Content: content, ChunkType: types.ChunkTypeText}
+	c.ContentHash = types.DocumentChunkHash("doc\n", c)
+	return c
+}
+
+func TestDiffVersionChunks(t *testing.T) {
+	oldA := versionTextChunk("old-a", 0, "A")
+	oldA.Flags = 1
+	oldB := versionTextChunk("old-b", 1, "B")
+	oldDup1 := versionTextChunk("old-dup-1", 2, "same")
+	oldDup2 := versionTextChunk("old-dup-2", 3, "same")
+	summary := &types.Chunk{ID: "old-summary", ChunkType: types.ChunkTypeSummary}
+	previous := []*types.Chunk{oldDup2, oldB, summary, oldA, oldDup1}
+
+	// New version: a paragraph inserted before A, B edited, one duplicate kept.
+	newChunks := []*types.Chunk{
+		versionTextChunk("new-0", 0, "Intro"),
+		versionTextChunk("new-1", 1, "A"),
+		versionTextChunk("new-2", 2, "B edited"),
+		versionTextChunk("new-3", 3, "same"),
+	}
+
+	got, diff := diffVersionChunks(previous, newChunks)
+	require.Len(t, got, 4)
+	assert.Equal(t, []string{"new-0", "old-a", "new-2", "old-dup-1"},
+		[]string{got[0].ID, got[1].ID, got[2].ID, got[3].ID})
+
+	assert.Same(t, oldA, got[1], "a reused chunk keeps its metadata and flags")
+	assert.Equal(t, 1, oldA.ChunkIndex)
+	assert.Equal(t, 100, oldA.StartAt)
+	assert.Equal(t, 3, oldDup1.ChunkIndex, "duplicates are matched in document order")
+
+	assert.Len(t, diff.reused, 2)
+	assert.True(t, diff.isReused("old-a"))
+	assert.False(t, diff.isReused("new-1"))
+	created := diff.created(got)
+	assert.Equal(t, []string{"new-0", "new-2"}, []string{created[0].ID, created[1].ID})
+
+	var removed []string
+	for _, c := range diff.removed {
+		removed = append(removed, c.ID)
+	}
+	assert.ElementsMatch(t, []string{"old-b", "old-dup-2", "old-summary"}, removed)
+	assert.Equal(t, 2, diff.removedTextCount())
+}
+
+func TestDocumentChunkHashCoversTitleAndHeader(t *testing.T) {
+	c := &types.Chunk{Content: "body"}
+	base := types.DocumentChunkHash("Title\n", c)
+	assert.NotEqual(t, base, types.DocumentChunkHash("Renamed\n", c), "a new title changes the embedded text")
+	c.ContextHeader = "Section 2"
+	assert.NotEqual(t, base, types.DocumentChunkHash("Title\n", c))
+}
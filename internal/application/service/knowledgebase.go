@@ -455,6 +455,9 @@ func (s *knowledgeBaseService) UpdateKnowledgeBase(ctx context.Context,
 		if config.WikiConfig != nil {
 			kb.WikiConfig = config.WikiConfig
 		}
+		if config.AutoTagConfig != nil {
+			kb.AutoTagConfig = config.AutoTagConfig
+		}
 		// Update indexing strategy — syncs to ExtractConfig for backward compat
 		if config.IndexingStrategy != nil {
 			if !config.IndexingStrategy.HasAnyIndexing() {
@@ -756,21 +759,29 @@ func (s *knowledgeBaseService) ProcessKBDelete(ctx context.Context, t *asynq.Tas
 				EmbeddingModelID string
 				Type             string
 			}
-			embeddingGroups := make(map[groupKey][]string)
+			embeddingGroups := make(map[groupKey]struct{})
 			for _, knowledge := range knowledgeList {
-				key := groupKey{EmbeddingModelID: knowledge.EmbeddingModelID, Type: knowledge.Type}
-				embeddingGroups[key] = append(embeddingGroups[key], knowledge.ID)
+				embeddingGroups[groupKey{EmbeddingModelID: knowledge.EmbeddingModelID, Type: knowledge.Type}] = struct{}{}
 			}
 
-			for key, knowledgeGroup := range embeddingGroups {
+			// One knowledge-base-wide delete per dimension removes every
+			// vector of the KB, and lets engines drop collections left empty.
+			deletedDimensions := make(map[int]bool)
+			for key := range embeddingGroups {
 				embeddingModel, err := s.modelService.GetEmbeddingModel(ctx, key.EmbeddingModelID)
 				if err != nil {
 					logger.Warnf(ctx, "Failed to get embedding model %s: %v", key.EmbeddingModelID, err)
 					continue
 				}
-				if err := retrieveEngine.DeleteByKnowledgeIDList(ctx, knowledgeGroup, embeddingModel.GetDimensions(), key.Type); err != nil {
+				dimension := embeddingModel.GetDimensions()
+				if deletedDimensions[dimension] {
+					continue
+				}
+				if err := retrieveEngine.DeleteByKnowledgeBaseIDList(ctx, []string{kbID}, dimension, key.Type); err != nil {
 					logger.Warnf(ctx, "Failed to delete embeddings for model %s: %v", key.EmbeddingModelID, err)
+					continue
 				}
+				deletedDimensions[dimension] = true
 			}
 		}
 
@@ -801,6 +812,7 @@ func (s *knowledgeBaseService) ProcessKBDelete(ctx context.Context, t *asynq.Tas
 				if err := s.fileSvc.DeleteFile(ctx, knowledge.FilePath); err != nil {
 					logger.Warnf(ctx, "Failed to delete file %s: %v", knowledge.FilePath, err)
 				}
+				deleteKnowledgePreview(ctx, s.fileSvc, knowledge)
 			}
 			storageAdjust -= knowledge.StorageSize
 		}
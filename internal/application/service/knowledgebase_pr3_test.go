@@ -117,6 +117,17 @@ func (r *fakeKBRepo) SetUserKBPin(_ context.Context, _ uint64, _ string, _ strin
 func (r *fakeKBRepo) ListUserKBPinIDs(_ context.Context, _ uint64, _ string) (map[string]time.Time, error) {
 	return map[string]time.Time{}, nil
 }
+func (r *fakeKBRepo) BeginIndexGeneration(_ context.Context, _ string) (int64, error) {
+	return 0, nil
+}
+func (r *fakeKBRepo) AbortIndexGeneration(_ context.Context, _ string, _ int64) error {
+	return nil
+}
+func (r *fakeKBRepo) SwitchIndexGeneration(
+	_ context.Context, _ string, _ int64, _ []types.ChunkType, _ string,
+) ([]string, error) {
+	return nil, nil
+}
 
 // Force compile-time conformance check so any future interface change
 // surfaces as a build error here rather than at usage site.
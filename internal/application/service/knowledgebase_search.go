@@ -130,6 +130,20 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		return nil, err
 	}
 
+	// Resolve the documents in scope whose ACL the caller does not match.
+	// Engines filter them out, so their chunks never reach ranking or the
+	// LLM context.
+	params.Principal = types.RetrievalPrincipalFromContext(ctx)
+	params.ACLDeniedKnowledgeIDs, err = s.aclDeniedKnowledgeIDs(ctx, kbs, params.Principal)
+	if err != nil {
+		return nil, err
+	}
+
+	// Chunks staged by a running index migration are filtered out by the
+	// engines too, before ranking, so they cannot take the place of live
+	// chunks in the top results.
+	params.StagedChunkIDs = s.stagedChunkIDs(ctx, kbs)
+
 	// Explicit embedding-model consistency check. Multi-KB searches that
 	// span different embedding spaces would otherwise silently produce
 	// meaningless cross-model scores. Same-model wiki/graph KBs are
@@ -209,7 +223,17 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 			"has_query_embedding": len(params.QueryEmbedding) > 0,
 		},
 	})
+	var retrievalCfg *types.RetrievalConfig
+	if tenantInfo != nil {
+		retrievalCfg = tenantInfo.RetrievalConfig
+	}
 	retrieveResults, err := s.retrieveFromStores(retrieveCtx, groups, retriever.EngineAwareNormalizer{})
+	if err != nil {
+		// A failed engine (typically an unreachable vector store) is handed
+		// to the tenant's degradation policy before it can reach the user.
+		retrieveResults, err = s.degradeRetrieval(
+			retrieveCtx, groups, retrievalCfg.GetEffectiveDegradationPolicy(), err)
+	}
 	retrieveSpan.Finish(langfuse.SummarizeRetrieveOutput(retrieveResults), nil, err)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
@@ -228,10 +252,6 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 	logger.Infof(ctx, "Result count before fusion: vector=%d, keyword=%d",
 		len(vectorResults), len(keywordResults))
 
-	var retrievalCfg *types.RetrievalConfig
-	if tenantInfo != nil {
-		retrievalCfg = tenantInfo.RetrievalConfig
-	}
 	deduplicatedChunks := fuseOrDeduplicate(ctx, vectorResults, keywordResults, retrievalCfg)
 
 	kb.EnsureDefaults()
@@ -251,7 +271,59 @@ func (s *knowledgeBaseService) HybridSearch(ctx context.Context,
 		deduplicatedChunks = deduplicatedChunks[:params.MatchCount]
 	}
 
-	return s.processSearchResults(ctx, deduplicatedChunks, params.SkipContextEnrichment)
+	results, err := s.processSearchResults(ctx, deduplicatedChunks, params.SkipContextEnrichment)
+	if err != nil {
+		return nil, err
+	}
+	return dropACLDeniedResults(results, params.ACLDeniedKnowledgeIDs), nil
+}
+
+// aclDeniedKnowledgeIDs returns the documents of the knowledge bases whose
+// ACL the principal does not match.
+func (s *knowledgeBaseService) aclDeniedKnowledgeIDs(
+	ctx context.Context,
+	kbs []*types.KnowledgeBase,
+	principal *types.RetrievalPrincipal,
+) ([]string, error) {
+	kbIDs := make([]string, 0, len(kbs))
+	for _, kb := range kbs {
+		kbIDs = append(kbIDs, kb.ID)
+	}
+	restricted, err := s.kgRepo.ListACLKnowledge(ctx, kbIDs)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to load document ACLs of knowledge bases %v: %v", kbIDs, err)
+		return nil, err
+	}
+	var denied []string
+	for _, k := range restricted {
+		if !k.ReadableBy(principal) {
+			denied = append(denied, k.ID)
+		}
+	}
+	if len(denied) > 0 {
+		logger.Infof(ctx, "Document ACLs hide %d of %d restricted documents from the caller",
+			len(denied), len(restricted))
+	}
+	return denied, nil
+}
+
+// dropACLDeniedResults removes results of denied documents. Engines already
+// filter them out; this also covers chunks added by context enrichment.
+func dropACLDeniedResults(results []*types.SearchResult, denied []string) []*types.SearchResult {
+	if len(denied) == 0 {
+		return results
+	}
+	deniedSet := make(map[string]struct{}, len(denied))
+	for _, id := range denied {
+		deniedSet[id] = struct{}{}
+	}
+	kept := results[:0]
+	for _, r := range results {
+		if _, ok := deniedSet[r.KnowledgeID]; !ok {
+			kept = append(kept, r)
+		}
+	}
+	return kept
 }
 
 // pickPrimary returns the KB whose ID matches id, or nil if id is not in
@@ -360,15 +432,19 @@ func (s *knowledgeBaseService) buildRetrievalParams(
 
 		appendVectorParams := func(kbIDs []string, knowledgeType string) {
 			retrieveParams = append(retrieveParams, types.RetrieveParams{
-				Query:            params.QueryText,
-				Embedding:        queryEmbedding,
-				KnowledgeBaseIDs: kbIDs,
-				TopK:             matchCount,
-				Threshold:        params.VectorThreshold,
-				RetrieverType:    types.VectorRetrieverType,
-				KnowledgeIDs:     params.KnowledgeIDs,
-				TagIDs:           params.TagIDs,
-				KnowledgeType:    knowledgeType,
+				Query:                 params.QueryText,
+				Embedding:             queryEmbedding,
+				EmbeddingModelID:      primary.EmbeddingModelID,
+				KnowledgeBaseIDs:      kbIDs,
+				TopK:                  matchCount,
+				Threshold:             params.VectorThreshold,
+				RetrieverType:         types.VectorRetrieverType,
+				KnowledgeIDs:          params.KnowledgeIDs,
+				TagIDs:                params.TagIDs,
+				KnowledgeType:         knowledgeType,
+				Principal:             params.Principal,
+				ACLDeniedKnowledgeIDs: params.ACLDeniedKnowledgeIDs,
+				StagedChunkIDs:        params.StagedChunkIDs,
 			})
 		}
 
@@ -390,13 +466,16 @@ func (s *knowledgeBaseService) buildRetrievalParams(
 		len(docKeywordKBIDs) > 0 {
 		logger.Info(ctx, "Keyword retrieval supported, preparing keyword retrieval parameters")
 		retrieveParams = append(retrieveParams, types.RetrieveParams{
-			Query:            params.QueryText,
-			KnowledgeBaseIDs: docKeywordKBIDs,
-			TopK:             matchCount,
-			Threshold:        params.KeywordThreshold,
-			RetrieverType:    types.KeywordsRetrieverType,
-			KnowledgeIDs:     params.KnowledgeIDs,
-			TagIDs:           params.TagIDs,
+			Query:                 params.QueryText,
+			KnowledgeBaseIDs:      docKeywordKBIDs,
+			TopK:                  matchCount,
+			Threshold:             params.KeywordThreshold,
+			RetrieverType:         types.KeywordsRetrieverType,
+			KnowledgeIDs:          params.KnowledgeIDs,
+			TagIDs:                params.TagIDs,
+			Principal:             params.Principal,
+			ACLDeniedKnowledgeIDs: params.ACLDeniedKnowledgeIDs,
+			StagedChunkIDs:        params.StagedChunkIDs,
 		})
 		logger.Info(ctx, "Keyword retrieval parameters setup completed")
 	}
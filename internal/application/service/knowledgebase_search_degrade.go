@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// User-safe reasons recorded on the RetrievalStatus. They intentionally
+// carry no engine error text, store UUIDs or gRPC status codes.
+const (
+	degradeReasonKeywordFallback = "vector store unavailable; served keyword results only"
+	degradeReasonUnavailable     = "knowledge retrieval is temporarily unavailable"
+)
+
+// degradeRetrieval applies the tenant's RetrievalDegradationPolicy after
+// retrieveFromStores failed with cause. It is the partial-result hook that
+// retrieveFromStores' doc comment leaves open: the fan-out itself stays
+// all-or-nothing, and the policy decides what to salvage afterwards.
+//
+//   - fail             → cause is returned unchanged (historical behavior).
+//   - keyword_fallback → the groups are re-run with keyword retrievers only.
+//     For env-store groups the composite routes keywords to whichever engine
+//     the tenant configured for them, which is typically a different engine
+//     than the failed vector store. If the keyword pass fails too, or no
+//     group has keyword params, the policy degrades further to unavailable.
+//   - unavailable      → an empty result set is returned and the request's
+//     RetrievalStatusRecorder is marked so callers can answer honestly.
+//
+// A caller-cancelled ctx always returns cause so a user stop is never
+// mistaken for an outage, and so does a cause that is not an outage (see
+// isStoreOutage): a misconfigured store must fail loudly rather than
+// quietly serve partial results.
+func (s *knowledgeBaseService) degradeRetrieval(
+	ctx context.Context,
+	groups []*storeGroup,
+	policy types.RetrievalDegradationPolicy,
+	cause error,
+) ([]*types.RetrieveResult, error) {
+	if policy == types.RetrievalDegradationFail || isParentCancelled(ctx) || !isStoreOutage(cause) {
+		return nil, cause
+	}
+	recorder := types.RetrievalStatusRecorderFromContext(ctx)
+
+	if policy == types.RetrievalDegradationKeywordFallback {
+		if keywordGroups := keywordOnlyGroups(groups); len(keywordGroups) > 0 {
+			results, err := s.retrieveFromStores(ctx, keywordGroups, retriever.EngineAwareNormalizer{})
+			if err == nil {
+				logger.WarnWithFields(ctx, logger.Fields{
+					"group_count": len(keywordGroups),
+					"cause":       cause.Error(),
+				}, "retrieval degraded to keyword-only results")
+				recorder.Record(types.RetrievalDegradationKeywordFallback, degradeReasonKeywordFallback)
+				return results, nil
+			}
+			if isParentCancelled(ctx) {
+				return nil, err
+			}
+			logger.WarnWithFields(ctx, logger.Fields{
+				"group_count": len(keywordGroups),
+				"cause":       err.Error(),
+			}, "keyword fallback failed; reporting retrieval unavailable")
+		}
+	}
+
+	logger.WarnWithFields(ctx, logger.Fields{
+		"group_count": len(groups),
+		"policy":      string(policy),
+		"cause":       cause.Error(),
+	}, "retrieval unavailable; returning empty result set")
+	recorder.Record(types.RetrievalDegradationUnavailable, degradeReasonUnavailable)
+	return nil, nil
+}
+
+// isStoreOutage reports whether a retrieve error means the store is
+// unavailable or timed out, as opposed to a configuration or validation
+// error that retrying or degrading would only hide.
+func isStoreOutage(err error) bool {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		switch appErr.Code {
+		case apperrors.ErrVectorStoreUnavailable, apperrors.ErrServiceUnavailable, apperrors.ErrTimeout:
+			return true
+		}
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	if st, ok := status.FromError(err); ok {
+		switch st.Code() {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+			return true
+		}
+	}
+	return false
+}
+
+// keywordOnlyGroups clones groups keeping only keyword RetrieveParams.
+// Groups without keyword params are dropped. The originals are left
+// untouched because BaseParams is shared with the FAQ iterative path.
+func keywordOnlyGroups(groups []*storeGroup) []*storeGroup {
+	out := make([]*storeGroup, 0, len(groups))
+	for _, g := range groups {
+		var params []types.RetrieveParams
+		for _, p := range g.BaseParams {
+			if p.RetrieverType == types.KeywordsRetrieverType {
+				params = append(params, p)
+			}
+		}
+		if len(params) == 0 {
+			continue
+		}
+		clone := *g
+		clone.BaseParams = params
+		out = append(out, &clone)
+	}
+	return out
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// vectorDownEngine fails every vector retrieve but serves keyword
+// retrieves, mimicking a Milvus outage next to a healthy keyword engine.
+type vectorDownEngine struct {
+	*fakeRetrieveEngineService
+}
+
+func (e *vectorDownEngine) Retrieve(ctx context.Context, p types.RetrieveParams) ([]*types.RetrieveResult, error) {
+	if p.RetrieverType == types.VectorRetrieverType {
+		e.retrieveCalls.Add(1)
+		return nil, status.Error(codes.Unavailable, "connection refused")
+	}
+	return e.fakeRetrieveEngineService.Retrieve(ctx, p)
+}
+
+func hybridParams(query string) []types.RetrieveParams {
+	return []types.RetrieveParams{
+		{Query: query, TopK: 50, RetrieverType: types.VectorRetrieverType},
+		{Query: query, TopK: 50, RetrieverType: types.KeywordsRetrieverType},
+	}
+}
+
+func TestDegradeRetrieval_FailPolicyReturnsCause(t *testing.T) {
+	t.Parallel()
+	cause := stderrors.New("boom")
+	ctx, rec := types.WithRetrievalStatusRecorder(context.Background())
+
+	res, err := (&knowledgeBaseService{}).degradeRetrieval(ctx, nil, types.RetrievalDegradationFail, cause)
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, cause)
+	assert.False(t, rec.Status().Degraded)
+}
+
+func TestDegradeRetrieval_KeywordFallback(t *testing.T) {
+	t.Parallel()
+	engine := &vectorDownEngine{&fakeRetrieveEngineService{
+		engineType: types.MilvusRetrieverEngineType,
+		support:    []types.RetrieverType{types.VectorRetrieverType, types.KeywordsRetrieverType},
+		canned:     []*types.IndexWithScore{{ChunkID: "kw-1", Score: 1}},
+	}}
+	groups := []*storeGroup{{
+		Engine:     buildBoundComposite(t, engine),
+		BaseParams: hybridParams("q"),
+		TopK:       50,
+		KBIDs:      []string{"kb-1"},
+	}}
+	s := &knowledgeBaseService{}
+	ctx, rec := types.WithRetrievalStatusRecorder(context.Background())
+
+	_, cause := s.retrieveFromStores(ctx, groups, retriever.EngineAwareNormalizer{})
+	require.Error(t, cause)
+
+	res, err := s.degradeRetrieval(ctx, groups, types.RetrievalDegradationKeywordFallback, cause)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, types.KeywordsRetrieverType, res[0].RetrieverType)
+	assert.Equal(t, "kw-1", res[0].Results[0].ChunkID)
+
+	status := rec.Status()
+	assert.True(t, status.Degraded)
+	assert.Equal(t, types.RetrievalDegradationKeywordFallback, status.Mode)
+	assert.NotContains(t, status.Reason, "rpc error")
+
+	// The original group must keep its vector params for later iterations.
+	assert.Len(t, groups[0].BaseParams, 2)
+}
+
+func TestDegradeRetrieval_KeywordFallbackFailsToUnavailable(t *testing.T) {
+	t.Parallel()
+	engine := &fakeRetrieveEngineService{
+		engineType: types.MilvusRetrieverEngineType,
+		support:    []types.RetrieverType{types.VectorRetrieverType, types.KeywordsRetrieverType},
+		cannedErr:  status.Error(codes.Unavailable, "connection refused"),
+	}
+	groups := []*storeGroup{{
+		Engine:     buildBoundComposite(t, engine),
+		BaseParams: hybridParams("q"),
+		TopK:       50,
+	}}
+	ctx, rec := types.WithRetrievalStatusRecorder(context.Background())
+
+	res, err := (&knowledgeBaseService{}).degradeRetrieval(
+		ctx, groups, types.RetrievalDegradationKeywordFallback, apperrors.NewVectorStoreUnavailableError(""))
+	require.NoError(t, err)
+	assert.Nil(t, res)
+	assert.True(t, rec.Status().Unavailable())
+}
+
+func TestDegradeRetrieval_UnavailableSkipsRetry(t *testing.T) {
+	t.Parallel()
+	engine := &fakeRetrieveEngineService{
+		engineType: types.MilvusRetrieverEngineType,
+		support:    []types.RetrieverType{types.VectorRetrieverType, types.KeywordsRetrieverType},
+	}
+	groups := []*storeGroup{{
+		Engine:     buildBoundComposite(t, engine),
+		BaseParams: hybridParams("q"),
+		TopK:       50,
+	}}
+	ctx, rec := types.WithRetrievalStatusRecorder(context.Background())
+
+	res, err := (&knowledgeBaseService{}).degradeRetrieval(
+		ctx, groups, types.RetrievalDegradationUnavailable, apperrors.NewVectorStoreUnavailableError(""))
+	require.NoError(t, err)
+	assert.Nil(t, res)
+	assert.True(t, rec.Status().Unavailable())
+	assert.Zero(t, engine.retrieveCalls.Load())
+}
+
+func TestDegradeRetrieval_CancelledContextReturnsCause(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	res, err := (&knowledgeBaseService{}).degradeRetrieval(
+		ctx, nil, types.RetrievalDegradationUnavailable, context.Canceled)
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDegradeRetrieval_ConfigErrorsPropagate(t *testing.T) {
+	t.Parallel()
+	mismatch := apperrors.NewValidationError("query embedding dimension does not match the collection")
+	engine := &fakeRetrieveEngineService{
+		engineType: types.MilvusRetrieverEngineType,
+		support:    []types.RetrieverType{types.VectorRetrieverType, types.KeywordsRetrieverType},
+		cannedErr:  mismatch,
+	}
+	group := func() *storeGroup {
+		return &storeGroup{Engine: buildBoundComposite(t, engine), BaseParams: hybridParams("q"), TopK: 50}
+	}
+	s := &knowledgeBaseService{}
+
+	for _, groups := range [][]*storeGroup{{group()}, {group(), group()}} {
+		_, cause := s.retrieveFromStores(context.Background(), groups, retriever.EngineAwareNormalizer{})
+		require.ErrorIs(t, cause, mismatch, "a typed error survives the fan-out of %d groups", len(groups))
+
+		for _, policy := range []types.RetrievalDegradationPolicy{
+			types.RetrievalDegradationKeywordFallback, types.RetrievalDegradationUnavailable,
+		} {
+			ctx, rec := types.WithRetrievalStatusRecorder(context.Background())
+			res, err := s.degradeRetrieval(ctx, groups, policy, cause)
+			assert.Nil(t, res)
+			assert.ErrorIs(t, err, mismatch, "policy %s", policy)
+			assert.False(t, rec.Status().Degraded)
+		}
+	}
+}
+
+func TestIsStoreOutage(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"typed unavailable", apperrors.NewVectorStoreUnavailableError(""), true},
+		{"deadline", fmt.Errorf("search: %w", context.DeadlineExceeded), true},
+		{"grpc unavailable", status.Error(codes.Unavailable, "connection refused"), true},
+		{"network", &net.OpError{Op: "dial", Err: stderrors.New("connection refused")}, true},
+		{"validation", apperrors.NewValidationError("dimension mismatch"), false},
+		{"binding invalid", apperrors.NewVectorStoreBindingInvalidError(""), false},
+		{"grpc invalid argument", status.Error(codes.InvalidArgument, "bad filter"), false},
+		{"untyped", stderrors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, isStoreOutage(tt.err), tt.name)
+	}
+}
@@ -95,7 +95,13 @@ func (s *knowledgeBaseService) retrieveFromStores(
 		if isParentCancelled(ctx) {
 			return nil, ctx.Err()
 		}
-		// Any retrieve failure (per-group timeout, transport error,
+		// Typed configuration and validation errors are already safe to
+		// show and must not be mistaken for an outage.
+		var appErr *apperrors.AppError
+		if errors.As(err, &appErr) && !isStoreOutage(appErr) {
+			return nil, appErr
+		}
+		// Any other retrieve failure (per-group timeout, transport error,
 		// upstream rejection) is collapsed into a single typed
 		// unavailable error. The underlying cause is recorded in
 		// structured logs above; the response body intentionally exposes
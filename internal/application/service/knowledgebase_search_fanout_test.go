@@ -408,6 +408,12 @@ func (f *fakeRetrieveEngineService) DeleteBySourceIDList(context.Context, []stri
 func (f *fakeRetrieveEngineService) DeleteByKnowledgeIDList(context.Context, []string, int, string) error {
 	panic("unused")
 }
+func (f *fakeRetrieveEngineService) DeleteByKnowledgeBaseIDList(context.Context, []string, int, string) error {
+	panic("unused")
+}
+func (f *fakeRetrieveEngineService) DeleteByTagIDList(context.Context, []string, int, string) error {
+	panic("unused")
+}
 func (f *fakeRetrieveEngineService) BatchUpdateChunkEnabledStatus(context.Context, map[string]bool) error {
 	panic("unused")
 }
@@ -3,7 +3,10 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"maps"
 	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/searchutil"
@@ -42,6 +45,7 @@ func (s *knowledgeBaseService) processSearchResults(ctx context.Context,
 		return nil, err
 	}
 	logger.Infof(ctx, "Chunk data fetched successfully, count: %d", len(allChunks))
+	allChunks = s.dropStagedChunks(ctx, allChunks, index)
 
 	// Build chunk map and collect enrichment IDs (parent, related, nearby)
 	chunkMap := make(map[string]*types.Chunk, len(allChunks))
@@ -81,6 +85,8 @@ func (s *knowledgeBaseService) processSearchResults(ctx context.Context,
 		}
 	}
 
+	index.hydrateMatchedContents(chunkMap)
+
 	// Build final search results
 	searchResults := s.assembleSearchResults(ctx, chunks, chunkMap, knowledgeMap, index, skipEnrichment)
 
@@ -90,6 +96,78 @@ func (s *knowledgeBaseService) processSearchResults(ctx context.Context,
 	return searchResults, nil
 }
 
+// stagedChunkIDs returns the chunks staged so far by the index migrations
+// running on the knowledge bases. The engine indexes them as soon as they
+// are embedded, so without this filter a query issued mid-migration would
+// see old and new chunks of the same document side by side. Knowledge
+// bases without a pending generation cost no lookup. A failed lookup is
+// logged and left to dropStagedChunks rather than failing the search.
+func (s *knowledgeBaseService) stagedChunkIDs(ctx context.Context, kbs []*types.KnowledgeBase) []string {
+	var staged []string
+	for _, kb := range kbs {
+		if kb.PendingIndexGeneration == 0 {
+			continue
+		}
+		ids, err := s.chunkRepo.ListChunkIDsByIndexGeneration(ctx, kb.TenantID, kb.ID, kb.PendingIndexGeneration)
+		if err != nil {
+			logger.Warnf(ctx, "Failed to list staged chunks of %s, filtering them after retrieval: %v", kb.ID, err)
+			continue
+		}
+		staged = append(staged, ids...)
+	}
+	if len(staged) > 0 {
+		logger.Infof(ctx, "Index migrations hide %d staged chunks from retrieval", len(staged))
+	}
+	return staged
+}
+
+// dropStagedChunks removes chunks written by an index migration that has not
+// switched its knowledge base over yet. Engines already filter out the
+// chunks stagedChunkIDs listed; this catches those staged after the listing
+// and leftovers of aborted attempts. Chunks outside a migration carry
+// generation 0, which skips the KB lookup.
+func (s *knowledgeBaseService) dropStagedChunks(
+	ctx context.Context, chunks []*types.Chunk, idx *chunkIndex,
+) []*types.Chunk {
+	kbIDs := make(map[string]struct{})
+	for _, chunk := range chunks {
+		if chunk.IndexGeneration > 0 {
+			kbIDs[chunk.KnowledgeBaseID] = struct{}{}
+		}
+	}
+	if len(kbIDs) == 0 {
+		return chunks
+	}
+	kbs, err := s.repo.GetKnowledgeBaseByIDs(ctx, slices.Collect(maps.Keys(kbIDs)))
+	if err != nil {
+		// Without the active generations nothing can be told apart; keep
+		// the results rather than failing the search.
+		logger.Warnf(ctx, "Failed to load index generations, skipping generation filter: %v", err)
+		return chunks
+	}
+	active := make(map[string]int64, len(kbs))
+	for _, kb := range kbs {
+		active[kb.ID] = kb.IndexGeneration
+	}
+
+	trace := types.RetrievalTraceFromContext(ctx)
+	kept := chunks[:0]
+	for _, chunk := range chunks {
+		if chunk.IndexGeneration > active[chunk.KnowledgeBaseID] {
+			trace.Exclude(types.ChunkExclusion{
+				ChunkID:     chunk.ID,
+				KnowledgeID: chunk.KnowledgeID,
+				Stage:       "search",
+				Reason:      types.ChunkExclusionInactiveGeneration,
+				Score:       idx.scores[chunk.ID],
+			})
+			continue
+		}
+		kept = append(kept, chunk)
+	}
+	return kept
+}
+
 // chunkIndex holds pre-computed lookup structures for processing search results.
 type chunkIndex struct {
 	knowledgeIDs    []string
@@ -98,6 +176,9 @@ type chunkIndex struct {
 	matchTypes      map[string]types.MatchType
 	matchedContents map[string]string
 	processedIDs    map[string]bool // tracks all IDs (chunk + enrichment) to avoid duplicates
+	// referenceSources maps chunk ID to source ID for hits whose engine
+	// stores no content (content reference mode); see hydrateMatchedContents.
+	referenceSources map[string]string
 }
 
 // buildChunkIndex collects knowledge/chunk IDs and builds score/matchType maps
@@ -120,10 +201,48 @@ func (s *knowledgeBaseService) buildChunkIndex(chunks []*types.IndexWithScore) *
 		idx.scores[chunk.ChunkID] = chunk.Score
 		idx.matchTypes[chunk.ChunkID] = chunk.MatchType
 		idx.matchedContents[chunk.ChunkID] = chunk.Content
+		if chunk.Content == "" {
+			if idx.referenceSources == nil {
+				idx.referenceSources = make(map[string]string)
+			}
+			idx.referenceSources[chunk.ChunkID] = chunk.SourceID
+		}
 	}
 	return idx
 }
 
+// hydrateMatchedContents fills matched content for hits returned without
+// content by engines in content reference mode, using the chunks already
+// batch-fetched for the result page. FAQ hits resolve to the question that
+// was indexed under their source ID ("<chunkID>" for the standard question,
+// "<chunkID>-<i>" for similar question i); other chunks use their content.
+func (idx *chunkIndex) hydrateMatchedContents(chunkMap map[string]*types.Chunk) {
+	for chunkID, sourceID := range idx.referenceSources {
+		chunk, ok := chunkMap[chunkID]
+		if !ok {
+			continue
+		}
+		idx.matchedContents[chunkID] = referencedContent(chunk, sourceID)
+	}
+}
+
+// referencedContent resolves the text indexed under sourceID for chunk.
+func referencedContent(chunk *types.Chunk, sourceID string) string {
+	if chunk.ChunkType != types.ChunkTypeFAQ {
+		return chunk.Content
+	}
+	meta, err := chunk.FAQMetadata()
+	if err != nil || meta == nil {
+		return chunk.Content
+	}
+	if suffix, ok := strings.CutPrefix(sourceID, chunk.ID+"-"); ok {
+		if i, err := strconv.Atoi(suffix); err == nil && i >= 0 && i < len(meta.SimilarQuestions) {
+			return meta.SimilarQuestions[i]
+		}
+	}
+	return meta.StandardQuestion
+}
+
 // collectEnrichmentChunkIDs gathers IDs for parent, related, and nearby chunks
 // that should be fetched to enrich the search results.
 func (s *knowledgeBaseService) collectEnrichmentChunkIDs(
@@ -237,6 +356,18 @@ func (s *knowledgeBaseService) assembleSearchResults(
 		}
 
 		score := idx.scores[chunk.ID]
+		if !chunk.IsEnabled {
+			// The index lags behind the enabled flag until the status sync
+			// lands; never surface a chunk the user switched off.
+			types.RetrievalTraceFromContext(ctx).Exclude(types.ChunkExclusion{
+				ChunkID:     chunk.ID,
+				KnowledgeID: chunk.KnowledgeID,
+				Stage:       "search",
+				Reason:      types.ChunkExclusionDisabledChunk,
+				Score:       score,
+			})
+			continue
+		}
 		if knowledge, ok := knowledgeMap[chunk.KnowledgeID]; ok {
 			matchType := idx.matchTypes[chunk.ID]
 			matchedContent := idx.matchedContents[chunk.ID]
@@ -256,7 +387,7 @@ func (s *knowledgeBaseService) assembleSearchResults(
 	// Second pass: Add enrichment chunks (parent, nearby, relation)
 	if !skipEnrichment {
 		for chunkID, chunk := range chunkMap {
-			if addedChunkIDs[chunkID] || !s.isSearchableChunk(chunk) {
+			if addedChunkIDs[chunkID] || !s.isSearchableChunk(chunk) || !chunk.IsEnabled {
 				continue
 			}
 
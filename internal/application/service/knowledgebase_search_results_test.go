@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHydrateMatchedContents_ContentReference(t *testing.T) {
+	t.Parallel()
+	faq := &types.Chunk{ID: "faq-1", ChunkType: types.ChunkTypeFAQ}
+	require.NoError(t, faq.SetFAQMetadata(&types.FAQChunkMetadata{
+		StandardQuestion: "How do I reset my password?",
+		SimilarQuestions: []string{"forgot password", "change password"},
+	}))
+	text := &types.Chunk{ID: "text-1", ChunkType: types.ChunkTypeText, Content: "full chunk text"}
+
+	idx := (&knowledgeBaseService{}).buildChunkIndex([]*types.IndexWithScore{
+		{ChunkID: "faq-1", SourceID: "faq-1-1"},
+		{ChunkID: "text-1", SourceID: "text-1"},
+		{ChunkID: "kept", SourceID: "kept", Content: "stored by engine"},
+	})
+	idx.hydrateMatchedContents(map[string]*types.Chunk{"faq-1": faq, "text-1": text})
+
+	assert.Equal(t, "change password", idx.matchedContents["faq-1"])
+	assert.Equal(t, "full chunk text", idx.matchedContents["text-1"])
+	assert.Equal(t, "stored by engine", idx.matchedContents["kept"])
+}
+
+func TestReferencedContent_FAQStandardQuestionFallback(t *testing.T) {
+	t.Parallel()
+	faq := &types.Chunk{ID: "faq-1", ChunkType: types.ChunkTypeFAQ}
+	require.NoError(t, faq.SetFAQMetadata(&types.FAQChunkMetadata{StandardQuestion: "Q"}))
+
+	assert.Equal(t, "Q", referencedContent(faq, "faq-1"))
+	// Out-of-range similar question index falls back to the standard question.
+	assert.Equal(t, "Q", referencedContent(faq, "faq-1-7"))
+}
+
+// stagedChunkRepo serves the staged chunk IDs of one generation per KB.
+type stagedChunkRepo struct {
+	interfaces.ChunkRepository
+	staged map[string][]string // "kbID/generation" -> chunk IDs
+	failKB string
+	calls  []string
+}
+
+func (r *stagedChunkRepo) ListChunkIDsByIndexGeneration(
+	_ context.Context, _ uint64, kbID string, generation int64,
+) ([]string, error) {
+	key := kbID + "/" + strconv.FormatInt(generation, 10)
+	r.calls = append(r.calls, key)
+	if kbID == r.failKB {
+		return nil, errors.New("db down")
+	}
+	return r.staged[key], nil
+}
+
+func TestStagedChunkIDs(t *testing.T) {
+	t.Parallel()
+	repo := &stagedChunkRepo{
+		staged: map[string][]string{"kb-migrating/3": {"s1", "s2"}, "kb-other/1": {"o1"}},
+		failKB: "kb-broken",
+	}
+	svc := &knowledgeBaseService{chunkRepo: repo}
+
+	staged := svc.stagedChunkIDs(context.Background(), []*types.KnowledgeBase{
+		{ID: "kb-idle", IndexGeneration: 2},
+		{ID: "kb-migrating", IndexGeneration: 2, PendingIndexGeneration: 3},
+		{ID: "kb-broken", PendingIndexGeneration: 1},
+		{ID: "kb-other", PendingIndexGeneration: 1},
+	})
+	assert.Equal(t, []string{"s1", "s2", "o1"}, staged)
+	assert.Equal(t, []string{"kb-migrating/3", "kb-broken/1", "kb-other/1"}, repo.calls,
+		"only KBs with a pending generation are looked up")
+
+	assert.Nil(t, svc.stagedChunkIDs(context.Background(), []*types.KnowledgeBase{{ID: "kb-idle"}}))
+}
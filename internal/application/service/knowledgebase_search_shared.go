@@ -130,6 +130,13 @@ func (s *knowledgeBaseService) listChunksByIDWithShared(ctx context.Context,
 		}
 		if !hasPermission {
 			logger.Debugf(ctx, "[listChunksByIDWithShared] No permission for KB %s", c.KnowledgeBaseID)
+			types.RetrievalTraceFromContext(ctx).Exclude(types.ChunkExclusion{
+				ChunkID:     c.ID,
+				KnowledgeID: c.KnowledgeID,
+				Stage:       "search",
+				Reason:      types.ChunkExclusionACL,
+				Detail:      "no access to knowledge base " + c.KnowledgeBaseID,
+			})
 			continue
 		}
 		chunks = append(chunks, c)
@@ -0,0 +1,374 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+const (
+	envConsolidationIntervalHours = "MEMORY_CONSOLIDATION_INTERVAL_HOURS"
+	envConsolidationSimilarity    = "MEMORY_CONSOLIDATION_SIMILARITY"
+
+	defaultConsolidationInterval   = 24 * time.Hour
+	defaultConsolidationSimilarity = 0.88
+	// consolidationStartupDelay holds the first run until after boot.
+	consolidationStartupDelay = 15 * time.Minute
+	// consolidationTenantTimeout bounds one tenant's run, LLM calls included.
+	consolidationTenantTimeout = 10 * time.Minute
+	// consolidationEntityLimit caps the entities compared per tenant; the
+	// most mentioned ones are the ones worth deduplicating.
+	consolidationEntityLimit = 2000
+	// consolidationMaxClusters caps the LLM calls made per tenant and run.
+	consolidationMaxClusters = 50
+	// consolidationClusterSize caps the candidates shown to the LLM at once.
+	consolidationClusterSize = 8
+)
+
+const adjudicateEntitiesPrompt = `
+You are an AI assistant that deduplicates the entities of a knowledge graph.
+The following entities have similar names. Decide which of them refer to the
+same real-world thing, e.g. "Tencent" and "Tencent Inc.". Entities that only
+share a topic, or are a part or a kind of another, are NOT the same.
+
+For each set of entities that are the same, pick the clearest, most complete
+name as "canonical" and list the other names as "duplicates". Use the names
+exactly as given. Leave out entities that have no duplicate.
+Output the result in JSON format:
+{
+  "groups": [
+    {"canonical": "Entity Name", "duplicates": ["Other Name"]}
+  ]
+}
+
+Entities:
+%s
+`
+
+type mergeGroup struct {
+	Canonical  string   `json:"canonical" jsonschema:"the name the merged entity keeps"`
+	Duplicates []string `json:"duplicates" jsonschema:"names of the entities that are the same as canonical"`
+}
+
+type adjudicationResult struct {
+	Groups []mergeGroup `json:"groups"`
+}
+
+// ConsolidationRunner periodically merges duplicate entities of the memory
+// graph. SaveEpisode MERGEs entities by exact name, so "Tencent" and
+// "Tencent Inc." end up as separate nodes; each run embeds the names of the
+// entities a tenant's episodes mention, groups the ones whose embeddings
+// are close, and lets the tenant's chat model decide which really are the
+// same. Merged names are kept as aliases on the surviving entity.
+//
+// Each tenant has its own entity nodes, so a merge decided with one tenant's
+// models never touches another tenant's graph.
+type ConsolidationRunner struct {
+	repo         interfaces.MemoryRepository
+	tenantRepo   interfaces.TenantRepository
+	modelService interfaces.ModelService
+	interval     time.Duration
+	similarity   float64
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	started   atomic.Bool
+}
+
+// NewConsolidationRunner constructs the runner, reading its schedule from
+// MEMORY_CONSOLIDATION_INTERVAL_HOURS (0 disables it) and its similarity
+// threshold from MEMORY_CONSOLIDATION_SIMILARITY. Nothing fires until Start
+// is called.
+func NewConsolidationRunner(
+	repo interfaces.MemoryRepository,
+	tenantRepo interfaces.TenantRepository,
+	modelService interfaces.ModelService,
+) *ConsolidationRunner {
+	interval := defaultConsolidationInterval
+	if v := strings.TrimSpace(os.Getenv(envConsolidationIntervalHours)); v != "" {
+		if hours, err := strconv.Atoi(v); err == nil && hours >= 0 {
+			interval = time.Duration(hours) * time.Hour
+		}
+	}
+	similarity := defaultConsolidationSimilarity
+	if v := strings.TrimSpace(os.Getenv(envConsolidationSimilarity)); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 && f < 1 {
+			similarity = f
+		}
+	}
+	return &ConsolidationRunner{
+		repo:         repo,
+		tenantRepo:   tenantRepo,
+		modelService: modelService,
+		interval:     interval,
+		similarity:   similarity,
+		stopCh:       make(chan struct{}),
+		doneCh:       make(chan struct{}),
+	}
+}
+
+// Start spins up the background goroutine when the memory graph is
+// available and consolidation is enabled. Calling it more than once is a
+// no-op.
+func (r *ConsolidationRunner) Start(ctx context.Context) {
+	if r == nil || r.repo == nil || r.interval <= 0 || !r.repo.IsAvailable(ctx) {
+		return
+	}
+	r.startOnce.Do(func() {
+		r.started.Store(true)
+		logger.Infof(ctx, "[memory-consolidation] starting: interval=%s similarity=%.2f",
+			r.interval, r.similarity)
+		go r.loop()
+	})
+}
+
+// Stop signals the loop to exit and blocks until it returns. Idempotent.
+func (r *ConsolidationRunner) Stop() {
+	if r == nil || !r.started.Load() {
+		return
+	}
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+	})
+	<-r.doneCh
+}
+
+func (r *ConsolidationRunner) loop() {
+	defer close(r.doneCh)
+
+	startupTimer := time.NewTimer(consolidationStartupDelay)
+	defer startupTimer.Stop()
+	select {
+	case <-startupTimer.C:
+	case <-r.stopCh:
+		return
+	}
+
+	r.runOnce()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.runOnce()
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+// runOnce consolidates every tenant's entities. Failures are logged and
+// retried on the next run; duplicates only cost recall in the meantime.
+func (r *ConsolidationRunner) runOnce() {
+	ctx := context.Background()
+	tenants, err := r.tenantRepo.ListTenants(ctx)
+	if err != nil {
+		logger.Warnf(ctx, "[memory-consolidation] failed to list tenants: %v", err)
+		return
+	}
+	total := 0
+	for _, tenant := range tenants {
+		select {
+		case <-r.stopCh:
+			return
+		default:
+		}
+		merged, err := r.consolidateTenant(ctx, tenant)
+		if err != nil {
+			logger.Warnf(ctx, "[memory-consolidation] tenant %d: %v", tenant.ID, err)
+		}
+		total += merged
+	}
+	logger.Infof(ctx, "[memory-consolidation] run complete: merged=%d tenants=%d", total, len(tenants))
+}
+
+// consolidateTenant merges duplicates among the entities the tenant's
+// episodes mention and returns how many entities were merged away.
+func (r *ConsolidationRunner) consolidateTenant(ctx context.Context, tenant *types.Tenant) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, consolidationTenantTimeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, tenant.ID)
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenant)
+
+	entities, err := r.repo.ListTenantEntities(ctx, tenant.ID, consolidationEntityLimit)
+	if err != nil {
+		return 0, fmt.Errorf("list entities: %w", err)
+	}
+	if len(entities) < 2 {
+		return 0, nil
+	}
+
+	embeddingModelID, err := firstModelOfType(ctx, r.modelService, types.ModelTypeEmbedding)
+	if err != nil {
+		return 0, err
+	}
+	embedder, err := r.modelService.GetEmbeddingModel(ctx, embeddingModelID)
+	if err != nil {
+		return 0, fmt.Errorf("get embedding model: %w", err)
+	}
+	chatModel, err := memoryChatModel(ctx, r.modelService, tenant.MemoryExtractionConfig)
+	if err != nil {
+		return 0, err
+	}
+
+	names := make([]string, len(entities))
+	for i, entity := range entities {
+		names[i] = entity.Name
+	}
+	vectors, err := embedder.BatchEmbed(ctx, names)
+	if err != nil {
+		return 0, fmt.Errorf("embed entity names: %w", err)
+	}
+	if len(vectors) != len(entities) {
+		return 0, fmt.Errorf("embedding model returned %d vectors for %d entities", len(vectors), len(entities))
+	}
+
+	merged := 0
+	for _, cluster := range candidateClusters(entities, vectors, r.similarity, consolidationMaxClusters) {
+		groups, err := adjudicate(ctx, chatModel, cluster)
+		if err != nil {
+			logger.Warnf(ctx, "[memory-consolidation] tenant %d: adjudication failed: %v", tenant.ID, err)
+			continue
+		}
+		for _, group := range groups {
+			if err := r.repo.MergeEntities(ctx, tenant.ID, group.Canonical, group.Duplicates); err != nil {
+				logger.Warnf(ctx, "[memory-consolidation] tenant %d: merge into %q failed: %v",
+					tenant.ID, group.Canonical, err)
+				continue
+			}
+			logger.Infof(ctx, "[memory-consolidation] tenant %d: merged %q into %q",
+				tenant.ID, group.Duplicates, group.Canonical)
+			merged += len(group.Duplicates)
+		}
+	}
+	return merged, nil
+}
+
+// candidateClusters groups entities whose name embeddings are at least
+// threshold apart in cosine similarity and whose types do not conflict.
+// Entities are taken in order (most mentioned first) as cluster seeds and
+// each entity joins at most one cluster. At most maxClusters clusters of
+// two or more entities are returned.
+func candidateClusters(entities []*types.MemoryEntity, vectors [][]float32,
+	threshold float64, maxClusters int,
+) [][]*types.MemoryEntity {
+	var clusters [][]*types.MemoryEntity
+	assigned := make([]bool, len(entities))
+	for i := range entities {
+		if len(clusters) >= maxClusters {
+			break
+		}
+		if assigned[i] {
+			continue
+		}
+		members := []int{i}
+		for j := i + 1; j < len(entities) && len(members) < consolidationClusterSize; j++ {
+			if assigned[j] || !typesCompatible(entities[i].Type, entities[j].Type) {
+				continue
+			}
+			if cosineSimilarity(vectors[i], vectors[j]) >= threshold {
+				members = append(members, j)
+			}
+		}
+		if len(members) < 2 {
+			continue
+		}
+		cluster := make([]*types.MemoryEntity, len(members))
+		for k, idx := range members {
+			assigned[idx] = true
+			cluster[k] = entities[idx]
+		}
+		clusters = append(clusters, cluster)
+	}
+	return clusters
+}
+
+// typesCompatible reports whether entities of the two types may be the
+// same; an unknown type is compatible with anything.
+func typesCompatible(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	return a == "" || b == "" || strings.EqualFold(a, b)
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// adjudicate asks the chat model which entities of the cluster are the
+// same and returns the merges it decided on.
+func adjudicate(ctx context.Context, chatModel chat.Chat, cluster []*types.MemoryEntity) ([]mergeGroup, error) {
+	var list strings.Builder
+	for _, entity := range cluster {
+		fmt.Fprintf(&list, "- name: %s\n  type: %s\n  description: %s\n",
+			entity.Name, entity.Type, entity.Description)
+	}
+	prompt := fmt.Sprintf(adjudicateEntitiesPrompt, list.String())
+	resp, err := chatModel.Chat(ctx, []chat.Message{{Role: "user", Content: prompt}}, &chat.ChatOptions{
+		Format: utils.GenerateSchema[adjudicationResult](),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call LLM: %v", err)
+	}
+	var result adjudicationResult
+	if err := json.Unmarshal([]byte(resp.Content), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse LLM response: %v", err)
+	}
+	return validMergeGroups(cluster, result.Groups), nil
+}
+
+// validMergeGroups drops what the model made up: names outside the
+// cluster, and names already used by an earlier group.
+func validMergeGroups(cluster []*types.MemoryEntity, groups []mergeGroup) []mergeGroup {
+	known := make(map[string]bool, len(cluster))
+	for _, entity := range cluster {
+		known[entity.Name] = true
+	}
+	used := make(map[string]bool)
+	var valid []mergeGroup
+	for _, group := range groups {
+		if !known[group.Canonical] || used[group.Canonical] {
+			continue
+		}
+		var duplicates []string
+		for _, name := range group.Duplicates {
+			if known[name] && !used[name] && name != group.Canonical {
+				used[name] = true
+				duplicates = append(duplicates, name)
+			}
+		}
+		if len(duplicates) == 0 {
+			continue
+		}
+		used[group.Canonical] = true
+		valid = append(valid, mergeGroup{Canonical: group.Canonical, Duplicates: duplicates})
+	}
+	return valid
+}
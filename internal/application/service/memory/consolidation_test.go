@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestCandidateClusters(t *testing.T) {
+	entities := []*types.MemoryEntity{
+		{Name: "Tencent", Type: "Organization"},
+		{Name: "Tencent Inc.", Type: "organization"},
+		{Name: "Shenzhen", Type: "Location"},
+		{Name: "Tencent Holdings", Type: "Location"},
+		{Name: "WeChat"},
+	}
+	vectors := [][]float32{
+		{1, 0, 0},
+		{0.95, 0.05, 0},
+		{0, 1, 0},
+		{0.9, 0.1, 0},
+		{0.7, 0.7, 0},
+	}
+
+	clusters := candidateClusters(entities, vectors, 0.9, 10)
+	if len(clusters) != 1 {
+		t.Fatalf("got %d clusters, want 1", len(clusters))
+	}
+	var names []string
+	for _, e := range clusters[0] {
+		names = append(names, e.Name)
+	}
+	// "Tencent Holdings" is close but typed as a location, "WeChat" is too
+	// far away.
+	if want := []string{"Tencent", "Tencent Inc."}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("cluster = %v, want %v", names, want)
+	}
+
+	if got := candidateClusters(entities, vectors, 0.9, 0); len(got) != 0 {
+		t.Fatalf("maxClusters 0 should yield no clusters, got %d", len(got))
+	}
+}
+
+func TestValidMergeGroups(t *testing.T) {
+	cluster := []*types.MemoryEntity{{Name: "Tencent"}, {Name: "Tencent Inc."}, {Name: "腾讯"}}
+	groups := validMergeGroups(cluster, []mergeGroup{
+		{Canonical: "Tencent", Duplicates: []string{"Tencent Inc.", "Tencent", "Alibaba"}},
+		{Canonical: "腾讯", Duplicates: []string{"Tencent Inc."}},
+		{Canonical: "Unknown", Duplicates: []string{"腾讯"}},
+	})
+	want := []mergeGroup{{Canonical: "Tencent", Duplicates: []string{"Tencent Inc."}}}
+	if !reflect.DeepEqual(groups, want) {
+		t.Fatalf("groups = %+v, want %+v", groups, want)
+	}
+}
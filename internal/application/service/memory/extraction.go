@@ -0,0 +1,162 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	envExtractionWorkers    = "MEMORY_EXTRACTION_WORKERS"
+	envExtractionMaxRetry   = "MEMORY_EXTRACTION_MAX_RETRY"
+	defaultExtractionWorker = 4
+	defaultExtractionRetry  = 3
+	// extractionTimeout bounds one extraction: a few LLM and embedding
+	// calls plus the graph writes.
+	extractionTimeout = 5 * time.Minute
+	// extractionRetention keeps finished tasks, and with them their IDs,
+	// long enough to drop a turn that is submitted again.
+	extractionRetention = 24 * time.Hour
+)
+
+// extractionMaxRetry reads MEMORY_EXTRACTION_MAX_RETRY, keeping the default
+// for unset or invalid values.
+func extractionMaxRetry() int {
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(envExtractionMaxRetry))); err == nil && n >= 0 {
+		return n
+	}
+	return defaultExtractionRetry
+}
+
+// extractionTaskID derives the task ID from the session turn, so a turn
+// enqueued twice, e.g. when the stream reports completion twice, is only
+// extracted once.
+func extractionTaskID(payload *types.MemoryExtractPayload) string {
+	return fmt.Sprintf("memory_extract:%s:%s", payload.SessionID, payload.TurnID)
+}
+
+// EnqueueExtraction queues a chat turn for memory extraction. Failed
+// extractions are retried with backoff and, once out of retries, recorded
+// as dead letters like every other task. A turn that is already queued or
+// was recently extracted is silently skipped.
+func EnqueueExtraction(ctx context.Context, client interfaces.TaskEnqueuer, payload *types.MemoryExtractPayload) error {
+	langfuse.InjectTracing(ctx, payload)
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	opts := []asynq.Option{
+		asynq.Queue(types.QueueMemory),
+		asynq.MaxRetry(extractionMaxRetry()),
+		asynq.Timeout(extractionTimeout),
+	}
+	if payload.TurnID != "" {
+		opts = append(opts, asynq.TaskID(extractionTaskID(payload)), asynq.Retention(extractionRetention))
+	}
+	info, err := client.Enqueue(asynq.NewTask(types.TypeMemoryExtract, data), opts...)
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		logger.Infof(ctx, "memory extraction for session %s turn %s already queued", payload.SessionID, payload.TurnID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to enqueue memory extraction: %v", err)
+	}
+	logger.Infof(ctx, "enqueued memory extraction: id=%s queue=%s session=%s", info.ID, info.Queue, payload.SessionID)
+	return nil
+}
+
+// ExtractionHandler processes queued memory extraction tasks: it stores the
+// turn as an episode as the session's memory policy says and folds the session's overflowing turns into its
+// rolling summary. At most MEMORY_EXTRACTION_WORKERS extractions run at once
+// per process, however many task workers the queue is given, so chat bursts
+// do not flood the chat model.
+type ExtractionHandler struct {
+	service interfaces.MemoryService
+	slots   chan struct{}
+}
+
+// NewExtractionHandler constructs the handler from the MEMORY_* settings.
+func NewExtractionHandler(svc interfaces.MemoryService) interfaces.TaskHandler {
+	workers := defaultExtractionWorker
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(envExtractionWorkers))); err == nil && n > 0 {
+		workers = n
+	}
+	return &ExtractionHandler{service: svc, slots: make(chan struct{}, workers)}
+}
+
+// Handle implements interfaces.TaskHandler.
+func (h *ExtractionHandler) Handle(ctx context.Context, t *asynq.Task) error {
+	var payload types.MemoryExtractPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		logger.Errorf(ctx, "Failed to unmarshal memory extraction payload: %v", err)
+		return nil // Don't retry on unmarshal error
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+
+	select {
+	case h.slots <- struct{}{}:
+		defer func() { <-h.slots }()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return Extract(ctx, h.service, &payload)
+}
+
+// Extract stores a queued turn as its memory policy says and updates the
+// session's rolling summary. A disabled memory is not an error.
+func Extract(ctx context.Context, svc interfaces.MemoryService, payload *types.MemoryExtractPayload) error {
+	err := storeEpisode(ctx, svc, payload)
+	if errors.Is(err, types.ErrMemoryUnavailable) {
+		logger.Warnf(ctx, "memory is not available, dropping extraction for session %s", payload.SessionID)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add episode: %w", err)
+	}
+	if payload.Trigger == types.MemoryTriggerSessionEnd {
+		return nil
+	}
+	if err := svc.UpdateSessionSummary(ctx, payload.UserID, payload.SessionID); err != nil {
+		logger.Warnf(ctx, "failed to update session summary: %v", err)
+	}
+	return nil
+}
+
+// storeEpisode stores the payload's turns as the memory policy they were
+// queued under says.
+func storeEpisode(ctx context.Context, svc interfaces.MemoryService, payload *types.MemoryExtractPayload) error {
+	if payload.SummaryOnly {
+		return nil
+	}
+	switch payload.Trigger {
+	case types.MemoryTriggerEveryNTurns, types.MemoryTriggerSessionEnd:
+		return svc.AddSessionEpisode(ctx, payload.UserID, payload.SessionID, payload.Turns)
+	}
+	messages := []types.Message{
+		{Role: "user", Content: payload.Query},
+		{Role: "assistant", Content: payload.Answer},
+	}
+	if payload.Trigger == types.MemoryTriggerMemorable {
+		memorable, err := svc.IsMemorable(ctx, messages)
+		if err != nil {
+			return err
+		}
+		if !memorable {
+			logger.Infof(ctx, "session %s turn %s judged not memorable", payload.SessionID, payload.TurnID)
+			return nil
+		}
+	}
+	return svc.AddEpisode(ctx, payload.UserID, payload.SessionID, messages)
+}
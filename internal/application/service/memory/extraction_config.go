@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+// defaultEntityTypes describes entity types when the tenant has no taxonomy.
+const defaultEntityTypes = "a short type such as Person, Location or Concept"
+
+// extractionConfig returns the tenant's memory extraction config, or nil
+// for the built-in behaviour. Chat requests carry the tenant; queued
+// extractions only carry its ID, so the tenant is loaded then.
+func (s *MemoryService) extractionConfig(ctx context.Context) *types.MemoryExtractionConfig {
+	if tenant, ok := types.TenantInfoFromContext(ctx); ok && tenant != nil {
+		return tenant.MemoryExtractionConfig
+	}
+	tenantID, ok := types.TenantIDFromContext(ctx)
+	if !ok || tenantID == 0 || s.tenantService == nil {
+		return nil
+	}
+	tenant, err := s.tenantService.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		logger.Warnf(ctx, "failed to load tenant %d memory extraction config: %v", tenantID, err)
+		return nil
+	}
+	return tenant.MemoryExtractionConfig
+}
+
+// buildExtractionRequest renders the extraction prompt and picks the output
+// schema, applying the tenant's overrides.
+func buildExtractionRequest(config *types.MemoryExtractionConfig, knownFacts string, conversation string) (string, json.RawMessage) {
+	template := extractGraphPrompt
+	format := utils.GenerateSchema[extractionResult]()
+	entityTypes := defaultEntityTypes
+	if config != nil {
+		if strings.TrimSpace(config.Prompt) != "" {
+			template = config.Prompt
+		}
+		if len(config.OutputSchema) > 0 {
+			format = config.OutputSchema
+		}
+		if len(config.EntityTypes) > 0 {
+			entityTypes = "one of " + strings.Join(config.EntityTypes, ", ") + ", or " + types.MemoryEntityTypeOther
+		}
+	}
+	prompt := types.RenderPromptPlaceholders(template, types.PlaceholderValues{
+		"known_facts":  knownFacts,
+		"conversation": conversation,
+		"entity_types": entityTypes,
+	})
+	return prompt, format
+}
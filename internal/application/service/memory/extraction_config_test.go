@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestBuildExtractionRequest(t *testing.T) {
+	prompt, format := buildExtractionRequest(nil, "(none)", "user: hi\n")
+	if !strings.Contains(prompt, "user: hi") || !strings.Contains(prompt, defaultEntityTypes) || strings.Contains(prompt, "{{") {
+		t.Fatalf("built-in prompt not rendered:\n%s", prompt)
+	}
+	if !strings.Contains(string(format), "profile_facts") {
+		t.Fatalf("built-in schema = %s", format)
+	}
+
+	config := &types.MemoryExtractionConfig{
+		Prompt:       "Types: {{entity_types}}\nFacts: {{known_facts}}\n{{conversation}}",
+		EntityTypes:  []string{"Person", "Project"},
+		OutputSchema: json.RawMessage(`{"type": "object"}`),
+	}
+	prompt, format = buildExtractionRequest(config, "- name/name: Lin", "user: hi\n")
+	if want := "Types: one of Person, Project, or Other\nFacts: - name/name: Lin\nuser: hi\n"; prompt != want {
+		t.Fatalf("prompt = %q, want %q", prompt, want)
+	}
+	if string(format) != `{"type": "object"}` {
+		t.Fatalf("schema = %s, want the tenant's", format)
+	}
+
+	for in, want := range map[string]string{"person": "Person", " Project ": "Project", "Location": types.MemoryEntityTypeOther} {
+		if got := config.NormalizeEntityType(in); got != want {
+			t.Errorf("NormalizeEntityType(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if err := (&types.MemoryExtractionConfig{OutputSchema: json.RawMessage(`[1]`)}).Validate(); err == nil {
+		t.Error("a non-object output schema should be rejected")
+	}
+}
@@ -0,0 +1,150 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/hibiken/asynq"
+)
+
+type recordingEnqueuer struct {
+	tasks []*asynq.Task
+	ids   []string
+	err   error
+}
+
+func (e *recordingEnqueuer) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	e.tasks = append(e.tasks, task)
+	for _, opt := range opts {
+		if opt.Type() == asynq.TaskIDOpt {
+			e.ids = append(e.ids, opt.Value().(string))
+		}
+	}
+	return &asynq.TaskInfo{ID: "t", Queue: types.QueueMemory}, nil
+}
+
+type episodeRecorder struct {
+	interfaces.MemoryService
+	err       error
+	tenantID  uint64
+	messages  []types.Message
+	summary   bool
+	session   bool
+	turns     int
+	memorable bool
+}
+
+func (s *episodeRecorder) AddSessionEpisode(_ context.Context, _ string, _ string, turns int) error {
+	s.session = true
+	s.turns = turns
+	return s.err
+}
+
+func (s *episodeRecorder) IsMemorable(context.Context, []types.Message) (bool, error) {
+	return s.memorable, nil
+}
+
+func (s *episodeRecorder) AddEpisode(ctx context.Context, _ string, _ string, messages []types.Message) error {
+	s.tenantID, _ = types.TenantIDFromContext(ctx)
+	s.messages = messages
+	return s.err
+}
+
+func (s *episodeRecorder) UpdateSessionSummary(context.Context, string, string) error {
+	s.summary = true
+	return nil
+}
+
+func TestEnqueueExtraction(t *testing.T) {
+	ctx := context.Background()
+	client := &recordingEnqueuer{}
+	payload := &types.MemoryExtractPayload{TenantID: 1, UserID: "u1", SessionID: "s1", TurnID: "m1", Query: "q", Answer: "a"}
+
+	if err := EnqueueExtraction(ctx, client, payload); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.ids) != 1 || client.ids[0] != "memory_extract:s1:m1" {
+		t.Fatalf("task IDs = %v, want one per session turn", client.ids)
+	}
+
+	// A turn that is already queued is not an error.
+	client.err = asynq.ErrTaskIDConflict
+	if err := EnqueueExtraction(ctx, client, payload); err != nil {
+		t.Fatalf("duplicate turn: %v", err)
+	}
+	client.err = errors.New("redis down")
+	if err := EnqueueExtraction(ctx, client, payload); err == nil {
+		t.Fatal("want the enqueue error")
+	}
+}
+
+func TestExtractionHandler(t *testing.T) {
+	svc := &episodeRecorder{}
+	handler := NewExtractionHandler(svc)
+	data, _ := json.Marshal(types.MemoryExtractPayload{TenantID: 7, UserID: "u1", SessionID: "s1", Query: "q", Answer: "a"})
+	task := asynq.NewTask(types.TypeMemoryExtract, data)
+
+	if err := handler.Handle(context.Background(), task); err != nil {
+		t.Fatal(err)
+	}
+	if svc.tenantID != 7 || len(svc.messages) != 2 || svc.messages[1].Content != "a" || !svc.summary {
+		t.Fatalf("handled %+v", svc)
+	}
+
+	// Extraction failures are retried; a disabled memory is not.
+	svc.err = errors.New("llm timeout")
+	if err := handler.Handle(context.Background(), task); err == nil {
+		t.Fatal("want an error so the task is retried")
+	}
+	svc.err = types.ErrMemoryUnavailable
+	if err := handler.Handle(context.Background(), task); err != nil {
+		t.Fatalf("memory unavailable: %v", err)
+	}
+}
+
+func TestExtractionPolicies(t *testing.T) {
+	base := types.MemoryExtractPayload{TenantID: 1, UserID: "u1", SessionID: "s1", Query: "q", Answer: "a"}
+	cases := []struct {
+		name      string
+		trigger   types.MemoryTrigger
+		turns     int
+		skip      bool
+		memorable bool
+		// wantTurn: the turn is stored; wantSession: the session's latest
+		// turns are stored instead.
+		wantTurn, wantSession, wantSummary bool
+	}{
+		{name: "every turn", wantTurn: true, wantSummary: true},
+		{name: "summary only", skip: true, wantSummary: true},
+		{name: "memorable", trigger: types.MemoryTriggerMemorable, memorable: true, wantTurn: true, wantSummary: true},
+		{name: "not memorable", trigger: types.MemoryTriggerMemorable, wantSummary: true},
+		{name: "every n turns", trigger: types.MemoryTriggerEveryNTurns, turns: 3, wantSession: true, wantSummary: true},
+		{name: "session end", trigger: types.MemoryTriggerSessionEnd, wantSession: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &episodeRecorder{memorable: tc.memorable}
+			payload := base
+			payload.Trigger, payload.Turns, payload.SummaryOnly = tc.trigger, tc.turns, tc.skip
+			if err := Extract(context.Background(), svc, &payload); err != nil {
+				t.Fatal(err)
+			}
+			if got := svc.messages != nil; got != tc.wantTurn {
+				t.Errorf("turn stored = %v, want %v", got, tc.wantTurn)
+			}
+			if svc.session != tc.wantSession || svc.turns != tc.turns {
+				t.Errorf("session episode = %v of %d turns, want %v of %d", svc.session, svc.turns, tc.wantSession, tc.turns)
+			}
+			if svc.summary != tc.wantSummary {
+				t.Errorf("summary updated = %v, want %v", svc.summary, tc.wantSummary)
+			}
+		})
+	}
+}
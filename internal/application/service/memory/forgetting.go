@@ -0,0 +1,256 @@
+package memory
+
+import (
+	"context"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	envForgetPolicy          = "MEMORY_FORGET_POLICY"
+	envForgetMaxEpisodes     = "MEMORY_MAX_EPISODES_PER_USER"
+	envForgetMinImportance   = "MEMORY_FORGET_MIN_IMPORTANCE"
+	envForgetHalfLifeDays    = "MEMORY_DECAY_HALF_LIFE_DAYS"
+	forgetPolicyArchive      = "archive"
+	forgetPolicyDelete       = "delete"
+	defaultForgetMaxEpisodes = 500
+	// defaultForgetMinImportance forgets an average-salience episode that
+	// was never retrieved once it is about 1.6 half-lives old.
+	defaultForgetMinImportance = 0.35
+	defaultDecayHalfLife       = 30 * 24 * time.Hour
+	// defaultSalience is assumed for episodes stored before salience was
+	// rated.
+	defaultSalience = 0.5
+	// forgetMinAge protects recent episodes, which have had no chance to
+	// be retrieved yet.
+	forgetMinAge = 7 * 24 * time.Hour
+
+	forgettingInterval     = 24 * time.Hour
+	forgettingStartupDelay = 20 * time.Minute
+
+	// Weights of the importance score components; they sum to 1.
+	salienceWeight = 0.5
+	recencyWeight  = 0.3
+	accessWeight   = 0.2
+)
+
+// clampSalience bounds an LLM-rated salience to (0, 1], falling back to
+// defaultSalience when the model gave none.
+func clampSalience(v float64) float64 {
+	switch {
+	case v <= 0 || math.IsNaN(v):
+		return defaultSalience
+	case v > 1:
+		return 1
+	default:
+		return v
+	}
+}
+
+// episodeImportance scores an episode from 0 to 1 by its salience, how
+// recently it was created or retrieved (halving every halfLife) and how
+// often it was retrieved.
+func episodeImportance(ep *types.Episode, now time.Time, halfLife time.Duration) float64 {
+	lastUsed := ep.CreatedAt
+	if ep.LastAccessedAt.After(lastUsed) {
+		lastUsed = ep.LastAccessedAt
+	}
+	age := now.Sub(lastUsed)
+	if age < 0 {
+		age = 0
+	}
+	recency := math.Pow(0.5, float64(age)/float64(halfLife))
+	access := 1 - 1/float64(1+max(ep.AccessCount, 0))
+	return salienceWeight*clampSalience(ep.Salience) + recencyWeight*recency + accessWeight*access
+}
+
+// forgettingPolicy decides which of a user's episodes to forget.
+type forgettingPolicy struct {
+	// mode is forgetPolicyArchive or forgetPolicyDelete; "" disables
+	// forgetting.
+	mode          string
+	maxEpisodes   int
+	minImportance float64
+	halfLife      time.Duration
+}
+
+// loadForgettingPolicy reads the MEMORY_* forgetting settings, keeping the
+// defaults for unset or invalid values.
+func loadForgettingPolicy() forgettingPolicy {
+	p := forgettingPolicy{
+		maxEpisodes:   defaultForgetMaxEpisodes,
+		minImportance: defaultForgetMinImportance,
+		halfLife:      defaultDecayHalfLife,
+	}
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv(envForgetPolicy))); mode {
+	case forgetPolicyArchive, forgetPolicyDelete:
+		p.mode = mode
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(envForgetMaxEpisodes))); err == nil && n > 0 {
+		p.maxEpisodes = n
+	}
+	if f, err := strconv.ParseFloat(strings.TrimSpace(os.Getenv(envForgetMinImportance)), 64); err == nil && f >= 0 && f <= 1 {
+		p.minImportance = f
+	}
+	if days, err := strconv.Atoi(strings.TrimSpace(os.Getenv(envForgetHalfLifeDays))); err == nil && days > 0 {
+		p.halfLife = time.Duration(days) * 24 * time.Hour
+	}
+	return p
+}
+
+// selectForgotten returns the IDs of the episodes to forget: those scoring
+// below minImportance, then the lowest scoring ones until at most
+// maxEpisodes remain. Episodes younger than forgetMinAge are kept.
+func (p forgettingPolicy) selectForgotten(episodes []*types.Episode, now time.Time) []string {
+	type scored struct {
+		id    string
+		score float64
+	}
+	var candidates []scored
+	for _, ep := range episodes {
+		if now.Sub(ep.CreatedAt) < forgetMinAge {
+			continue
+		}
+		candidates = append(candidates, scored{ep.ID, episodeImportance(ep, now, p.halfLife)})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score < candidates[j].score })
+
+	excess := len(episodes) - p.maxEpisodes
+	var forgotten []string
+	for i, c := range candidates {
+		if c.score >= p.minImportance && i >= excess {
+			break
+		}
+		forgotten = append(forgotten, c.id)
+	}
+	return forgotten
+}
+
+// ForgettingRunner applies the forgetting policy once a day, archiving or
+// deleting each user's low-value episodes so the memory graph stays bounded
+// and retrieval keeps surfacing what matters. It stays dormant unless
+// MEMORY_FORGET_POLICY is "archive" or "delete".
+type ForgettingRunner struct {
+	repo   interfaces.MemoryRepository
+	policy forgettingPolicy
+	now    func() time.Time
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	started   atomic.Bool
+}
+
+// NewForgettingRunner constructs the runner from the MEMORY_* settings.
+// Nothing fires until Start is called.
+func NewForgettingRunner(repo interfaces.MemoryRepository) *ForgettingRunner {
+	return &ForgettingRunner{
+		repo:   repo,
+		policy: loadForgettingPolicy(),
+		now:    time.Now,
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+}
+
+// Start spins up the background goroutine when a policy is configured and
+// the memory graph is available. Calling it more than once is a no-op.
+func (r *ForgettingRunner) Start(ctx context.Context) {
+	if r == nil || r.repo == nil || r.policy.mode == "" || !r.repo.IsAvailable(ctx) {
+		return
+	}
+	r.startOnce.Do(func() {
+		r.started.Store(true)
+		logger.Infof(ctx, "[memory-forgetting] starting: policy=%s max_episodes=%d min_importance=%.2f half_life=%s",
+			r.policy.mode, r.policy.maxEpisodes, r.policy.minImportance, r.policy.halfLife)
+		go r.loop()
+	})
+}
+
+// Stop signals the loop to exit and blocks until it returns. Idempotent.
+func (r *ForgettingRunner) Stop() {
+	if r == nil || !r.started.Load() {
+		return
+	}
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+	})
+	<-r.doneCh
+}
+
+func (r *ForgettingRunner) loop() {
+	defer close(r.doneCh)
+
+	startupTimer := time.NewTimer(forgettingStartupDelay)
+	defer startupTimer.Stop()
+	select {
+	case <-startupTimer.C:
+	case <-r.stopCh:
+		return
+	}
+
+	r.runOnce(context.Background())
+
+	ticker := time.NewTicker(forgettingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.runOnce(context.Background())
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+// runOnce applies the policy to every user's episodes. Failures are logged
+// and retried on the next run.
+func (r *ForgettingRunner) runOnce(ctx context.Context) {
+	users, err := r.repo.ListMemoryUsers(ctx)
+	if err != nil {
+		logger.Warnf(ctx, "[memory-forgetting] failed to list users: %v", err)
+		return
+	}
+	total := 0
+	for _, user := range users {
+		select {
+		case <-r.stopCh:
+			return
+		default:
+		}
+		episodes, err := r.repo.ListUserEpisodes(ctx, user.TenantID, user.UserID)
+		if err != nil {
+			logger.Warnf(ctx, "[memory-forgetting] tenant %d user %s: failed to list episodes: %v",
+				user.TenantID, user.UserID, err)
+			continue
+		}
+		forgotten := r.policy.selectForgotten(episodes, r.now())
+		if len(forgotten) == 0 {
+			continue
+		}
+		if r.policy.mode == forgetPolicyDelete {
+			err = r.repo.DeleteEpisodes(ctx, user.TenantID, forgotten)
+		} else {
+			err = r.repo.ArchiveEpisodes(ctx, user.TenantID, forgotten)
+		}
+		if err != nil {
+			logger.Warnf(ctx, "[memory-forgetting] tenant %d user %s: failed to forget episodes: %v",
+				user.TenantID, user.UserID, err)
+			continue
+		}
+		total += len(forgotten)
+	}
+	logger.Infof(ctx, "[memory-forgetting] run complete: policy=%s forgotten=%d users=%d",
+		r.policy.mode, total, len(users))
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestEpisodeImportance(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	halfLife := 30 * 24 * time.Hour
+
+	fresh := &types.Episode{CreatedAt: now, Salience: 0.5}
+	if got := episodeImportance(fresh, now, halfLife); got < 0.549 || got > 0.551 {
+		t.Fatalf("fresh episode importance = %.3f, want 0.55", got)
+	}
+
+	stale := &types.Episode{CreatedAt: now.Add(-halfLife), Salience: 0.5}
+	accessed := &types.Episode{CreatedAt: now.Add(-halfLife), LastAccessedAt: now, AccessCount: 3, Salience: 0.5}
+	if episodeImportance(accessed, now, halfLife) <= episodeImportance(stale, now, halfLife) {
+		t.Fatal("a recently retrieved episode should outrank a stale one")
+	}
+	if got := episodeImportance(stale, now, halfLife); got < 0.399 || got > 0.401 {
+		t.Fatalf("importance after one half-life = %.3f, want 0.40", got)
+	}
+}
+
+func TestForgettingPolicySelectForgotten(t *testing.T) {
+	now := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	episodes := []*types.Episode{
+		{ID: "old-trivial", CreatedAt: now.Add(-120 * day), Salience: 0.1},
+		{ID: "old-salient", CreatedAt: now.Add(-120 * day), Salience: 1},
+		{ID: "mid", CreatedAt: now.Add(-20 * day), Salience: 0.5},
+		{ID: "used", CreatedAt: now.Add(-20 * day), LastAccessedAt: now.Add(-day), AccessCount: 5, Salience: 0.5},
+		{ID: "new-trivial", CreatedAt: now.Add(-day), Salience: 0.1},
+	}
+	p := forgettingPolicy{mode: forgetPolicyArchive, maxEpisodes: 10, minImportance: 0.35, halfLife: 30 * day}
+	if got := p.selectForgotten(episodes, now); !reflect.DeepEqual(got, []string{"old-trivial"}) {
+		t.Fatalf("forgotten = %v, want only the low-importance episode", got)
+	}
+
+	// Over the cap the lowest scoring episodes go too, but never recent ones.
+	p.maxEpisodes = 2
+	if got := p.selectForgotten(episodes, now); !reflect.DeepEqual(got, []string{"old-trivial", "mid", "old-salient"}) {
+		t.Fatalf("forgotten = %v", got)
+	}
+}
@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// exportPageSize is the page size used to read all of a user's episodes
+// for an export.
+const exportPageSize = 500
+
+// ListEpisodes lists a page of the user's episodes, newest first.
+func (s *MemoryService) ListEpisodes(ctx context.Context, userID string, page *types.Pagination) (*types.PageResult, error) {
+	if !s.repo.IsAvailable(ctx) {
+		return nil, types.ErrMemoryUnavailable
+	}
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	episodes, total, err := s.repo.ListEpisodes(ctx, tenantID, userID, page.Offset(), page.Limit())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list episodes: %v", err)
+	}
+	return types.NewPageResult(total, page, episodes), nil
+}
+
+// DeleteEpisode deletes one of the user's episodes.
+func (s *MemoryService) DeleteEpisode(ctx context.Context, userID string, episodeID string) error {
+	if !s.repo.IsAvailable(ctx) {
+		return types.ErrMemoryUnavailable
+	}
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	return s.repo.DeleteEpisode(ctx, tenantID, userID, episodeID)
+}
+
+// ListEntities lists the entities the user's episodes mention.
+func (s *MemoryService) ListEntities(ctx context.Context, userID string) ([]*types.MemoryEntity, error) {
+	if !s.repo.IsAvailable(ctx) {
+		return nil, types.ErrMemoryUnavailable
+	}
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	return s.repo.ListUserEntities(ctx, tenantID, userID)
+}
+
+// UpdateEntity corrects the type and description of an entity the user's
+// episodes mention.
+func (s *MemoryService) UpdateEntity(ctx context.Context, userID string, entity *types.MemoryEntity) error {
+	if !s.repo.IsAvailable(ctx) {
+		return types.ErrMemoryUnavailable
+	}
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	entity.Name = strings.TrimSpace(entity.Name)
+	if entity.Name == "" {
+		return types.ErrMemoryNotFound
+	}
+	return s.repo.UpdateEntity(ctx, tenantID, userID, entity)
+}
+
+// ListRelationships lists the relationships between the user's entities.
+func (s *MemoryService) ListRelationships(ctx context.Context, userID string) ([]*types.MemoryRelationship, error) {
+	if !s.repo.IsAvailable(ctx) {
+		return nil, types.ErrMemoryUnavailable
+	}
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	return s.repo.ListUserRelationships(ctx, tenantID, userID)
+}
+
+// ExportMemory returns everything remembered about the user.
+func (s *MemoryService) ExportMemory(ctx context.Context, userID string) (*types.MemoryExport, error) {
+	if !s.repo.IsAvailable(ctx) {
+		return nil, types.ErrMemoryUnavailable
+	}
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	export := &types.MemoryExport{UserID: userID, ExportedAt: time.Now()}
+
+	var err error
+	if export.Profile, err = s.repo.GetProfileFacts(ctx, tenantID, userID); err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %v", err)
+	}
+	for offset := 0; ; offset += exportPageSize {
+		episodes, total, err := s.repo.ListEpisodes(ctx, tenantID, userID, offset, exportPageSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list episodes: %v", err)
+		}
+		export.Episodes = append(export.Episodes, episodes...)
+		if len(episodes) < exportPageSize || int64(len(export.Episodes)) >= total {
+			break
+		}
+	}
+	if export.Entities, err = s.repo.ListUserEntities(ctx, tenantID, userID); err != nil {
+		return nil, fmt.Errorf("failed to list entities: %v", err)
+	}
+	if export.Relationships, err = s.repo.ListUserRelationships(ctx, tenantID, userID); err != nil {
+		return nil, fmt.Errorf("failed to list relationships: %v", err)
+	}
+	return export, nil
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+type pagedMemoryRepo struct {
+	interfaces.MemoryRepository
+	episodes []*types.Episode
+}
+
+func (r *pagedMemoryRepo) IsAvailable(context.Context) bool { return true }
+
+func (r *pagedMemoryRepo) GetProfileFacts(context.Context, uint64, string) ([]*types.UserProfileFact, error) {
+	return nil, nil
+}
+
+func (r *pagedMemoryRepo) ListEpisodes(_ context.Context, _ uint64, _ string, offset, limit int) ([]*types.Episode, int64, error) {
+	end := min(offset+limit, len(r.episodes))
+	return r.episodes[offset:end], int64(len(r.episodes)), nil
+}
+
+func (r *pagedMemoryRepo) ListUserEntities(context.Context, uint64, string) ([]*types.MemoryEntity, error) {
+	return nil, nil
+}
+
+func (r *pagedMemoryRepo) ListUserRelationships(context.Context, uint64, string) ([]*types.MemoryRelationship, error) {
+	return nil, nil
+}
+
+func TestExportMemoryReadsAllEpisodePages(t *testing.T) {
+	repo := &pagedMemoryRepo{}
+	for i := 0; i < exportPageSize+3; i++ {
+		repo.episodes = append(repo.episodes, &types.Episode{ID: fmt.Sprint(i)})
+	}
+	svc := &MemoryService{repo: repo}
+
+	export, err := svc.ExportMemory(context.Background(), "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(export.Episodes) != len(repo.episodes) {
+		t.Fatalf("exported %d episodes, want %d", len(export.Episodes), len(repo.episodes))
+	}
+	if export.UserID != "u1" {
+		t.Fatalf("user = %q", export.UserID)
+	}
+}
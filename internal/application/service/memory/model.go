@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// errNoMemoryModel is returned when the tenant has no chat model memory can
+// run on.
+var errNoMemoryModel = errors.New("no chat model available for memory")
+
+// memoryModelCandidates orders the tenant's chat-capable models the way
+// memory tries them: the models configured for memory, in the configured
+// order, then the default KnowledgeQA model, the other KnowledgeQA models
+// and finally vision chat (VLLM) models. Models still downloading or that
+// failed to download are left out.
+func memoryModelCandidates(config *types.MemoryExtractionConfig, models []*types.Model) []string {
+	usable := make(map[string]*types.Model, len(models))
+	for _, model := range models {
+		if model.Type != types.ModelTypeKnowledgeQA && model.Type != types.ModelTypeVLLM {
+			continue
+		}
+		if model.Status != "" && model.Status != types.ModelStatusActive {
+			continue
+		}
+		usable[model.ID] = model
+	}
+
+	var candidates []string
+	seen := make(map[string]bool)
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			candidates = append(candidates, id)
+		}
+	}
+	if config != nil {
+		for _, id := range config.ModelIDs {
+			if _, ok := usable[id]; ok {
+				add(id)
+			}
+		}
+	}
+	for _, pass := range []func(*types.Model) bool{
+		func(m *types.Model) bool { return m.Type == types.ModelTypeKnowledgeQA && m.IsDefault },
+		func(m *types.Model) bool { return m.Type == types.ModelTypeKnowledgeQA },
+		func(m *types.Model) bool { return m.Type == types.ModelTypeVLLM },
+	} {
+		for _, model := range models {
+			if _, ok := usable[model.ID]; ok && pass(model) {
+				add(model.ID)
+			}
+		}
+	}
+	return candidates
+}
+
+// memoryChatModel returns the first candidate chat model that loads, so a
+// deleted or misconfigured model degrades to the next one rather than
+// turning memory off.
+func memoryChatModel(
+	ctx context.Context, modelService interfaces.ModelService, config *types.MemoryExtractionConfig,
+) (chat.Chat, error) {
+	models, err := modelService.ListModels(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list models: %v", err)
+	}
+	var lastErr error
+	for _, modelID := range memoryModelCandidates(config, models) {
+		chatModel, err := modelService.GetChatModel(ctx, modelID)
+		if err == nil {
+			return chatModel, nil
+		}
+		logger.Warnf(ctx, "memory chat model %s unavailable, trying the next one: %v", modelID, err)
+		lastErr = err
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("%w: %v", errNoMemoryModel, lastErr)
+	}
+	return nil, errNoMemoryModel
+}
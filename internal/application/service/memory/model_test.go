@@ -0,0 +1,80 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestMemoryModelCandidates(t *testing.T) {
+	models := []*types.Model{
+		{ID: "emb", Type: types.ModelTypeEmbedding},
+		{ID: "vision", Type: types.ModelTypeVLLM},
+		{ID: "qa", Type: types.ModelTypeKnowledgeQA},
+		{ID: "qa-default", Type: types.ModelTypeKnowledgeQA, IsDefault: true},
+		{ID: "qa-pulling", Type: types.ModelTypeKnowledgeQA, Status: types.ModelStatusDownloading},
+		{ID: "small", Type: types.ModelTypeKnowledgeQA, Status: types.ModelStatusActive},
+	}
+
+	got := memoryModelCandidates(nil, models)
+	if want := []string{"qa-default", "qa", "small", "vision"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("candidates = %v, want %v", got, want)
+	}
+
+	config := &types.MemoryExtractionConfig{ModelIDs: []string{"small", "deleted", "emb", "qa-pulling"}}
+	got = memoryModelCandidates(config, models)
+	if want := []string{"small", "qa-default", "qa", "vision"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("candidates = %v, want configured models first, %v", got, want)
+	}
+
+	// A tenant with only a vision chat model still gets memory.
+	got = memoryModelCandidates(nil, []*types.Model{{ID: "vision", Type: types.ModelTypeVLLM}})
+	if want := []string{"vision"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("candidates = %v, want %v", got, want)
+	}
+}
+
+type flakyModelService struct {
+	fakeModelService
+	broken map[string]bool
+	tried  []string
+}
+
+func (s *flakyModelService) GetChatModel(_ context.Context, modelID string) (chat.Chat, error) {
+	s.tried = append(s.tried, modelID)
+	if s.broken[modelID] {
+		return nil, errors.New("invalid api key")
+	}
+	return fakeChat{}, nil
+}
+
+func TestMemoryChatModelFallsBack(t *testing.T) {
+	ctx := context.Background()
+	models := &flakyModelService{
+		fakeModelService: fakeModelService{models: []*types.Model{
+			{ID: "qa", Type: types.ModelTypeKnowledgeQA},
+			{ID: "vision", Type: types.ModelTypeVLLM},
+		}},
+		broken: map[string]bool{"qa": true},
+	}
+	if _, err := memoryChatModel(ctx, models, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"qa", "vision"}; !reflect.DeepEqual(models.tried, want) {
+		t.Fatalf("tried = %v, want %v", models.tried, want)
+	}
+
+	models.broken["vision"] = true
+	if _, err := memoryChatModel(ctx, models, nil); !errors.Is(err, errNoMemoryModel) {
+		t.Fatalf("err = %v, want errNoMemoryModel", err)
+	}
+
+	models.models = []*types.Model{{ID: "emb", Type: types.ModelTypeEmbedding}}
+	if _, err := memoryChatModel(ctx, models, nil); !errors.Is(err, errNoMemoryModel) {
+		t.Fatalf("err = %v, want errNoMemoryModel", err)
+	}
+}
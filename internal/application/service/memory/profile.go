@@ -0,0 +1,104 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// maxProfileFactLength bounds stored fact values; profile facts are
+// injected into every prompt.
+const maxProfileFactLength = 200
+
+type profileFactResult struct {
+	Category string `json:"category" jsonschema:"one of name, preference, role, project, other"`
+	Key      string `json:"key" jsonschema:"short snake_case identifier of the fact, e.g. preferred_language"`
+	Value    string `json:"value" jsonschema:"the fact; empty when a known fact no longer holds"`
+}
+
+var profileKeyInvalid = regexp.MustCompile(`[^\p{L}\p{N}_]+`)
+
+// normalizeProfileFacts cleans up the facts the LLM extracted: categories
+// outside the known set become "other", keys are lower snake_case, and
+// later facts win over earlier ones with the same key.
+func normalizeProfileFacts(results []profileFactResult) []*types.UserProfileFact {
+	var facts []*types.UserProfileFact
+	index := make(map[string]int)
+	for _, r := range results {
+		category := strings.ToLower(strings.TrimSpace(r.Category))
+		switch category {
+		case types.ProfileCategoryName, types.ProfileCategoryPreference, types.ProfileCategoryRole,
+			types.ProfileCategoryProject, types.ProfileCategoryOther:
+		default:
+			category = types.ProfileCategoryOther
+		}
+		key := strings.Trim(profileKeyInvalid.ReplaceAllString(strings.ToLower(strings.TrimSpace(r.Key)), "_"), "_")
+		if key == "" {
+			if category != types.ProfileCategoryName {
+				continue
+			}
+			key = types.ProfileCategoryName
+		}
+		value := strings.TrimSpace(r.Value)
+		if runes := []rune(value); len(runes) > maxProfileFactLength {
+			value = string(runes[:maxProfileFactLength])
+		}
+
+		fact := &types.UserProfileFact{Category: category, Key: key, Value: value}
+		id := category + "/" + key
+		if i, ok := index[id]; ok {
+			facts[i] = fact
+			continue
+		}
+		index[id] = len(facts)
+		facts = append(facts, fact)
+	}
+	return facts
+}
+
+// renderKnownFacts lists the user's facts for the extraction prompt.
+func renderKnownFacts(facts []*types.UserProfileFact) string {
+	if len(facts) == 0 {
+		return "(none)"
+	}
+	var b strings.Builder
+	for _, fact := range facts {
+		fmt.Fprintf(&b, "- %s/%s: %s\n", fact.Category, fact.Key, fact.Value)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// GetUserProfile returns the stable facts learned about the user.
+func (s *MemoryService) GetUserProfile(ctx context.Context, userID string) (*types.UserProfile, error) {
+	if !s.repo.IsAvailable(ctx) {
+		return nil, types.ErrMemoryUnavailable
+	}
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	facts, err := s.repo.GetProfileFacts(ctx, tenantID, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user profile: %v", err)
+	}
+	return &types.UserProfile{UserID: userID, Facts: facts}, nil
+}
+
+// DeleteProfileFact removes one fact from the user's profile.
+func (s *MemoryService) DeleteProfileFact(ctx context.Context, userID string, category string, key string) error {
+	if !s.repo.IsAvailable(ctx) {
+		return types.ErrMemoryUnavailable
+	}
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	facts, err := s.repo.GetProfileFacts(ctx, tenantID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get user profile: %v", err)
+	}
+	for _, fact := range facts {
+		if fact.Category == category && fact.Key == key {
+			removal := &types.UserProfileFact{Category: category, Key: key}
+			return s.repo.SaveProfileFacts(ctx, tenantID, userID, "", []*types.UserProfileFact{removal})
+		}
+	}
+	return types.ErrMemoryNotFound
+}
@@ -0,0 +1,41 @@
+package memory
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestNormalizeProfileFacts(t *testing.T) {
+	facts := normalizeProfileFacts([]profileFactResult{
+		{Category: "Preference", Key: "Preferred Language", Value: " Go "},
+		{Category: "hobby", Key: "climbing", Value: "bouldering"},
+		{Category: "name", Key: "", Value: "Lin"},
+		{Category: "project", Key: "", Value: "WeKnora"},
+		{Category: "preference", Key: "preferred-language", Value: "Rust"},
+		{Category: "role", Key: "job_title", Value: ""},
+	})
+	want := []*types.UserProfileFact{
+		{Category: "preference", Key: "preferred_language", Value: "Rust"},
+		{Category: "other", Key: "climbing", Value: "bouldering"},
+		{Category: "name", Key: "name", Value: "Lin"},
+		{Category: "role", Key: "job_title", Value: ""},
+	}
+	if !reflect.DeepEqual(facts, want) {
+		for _, f := range facts {
+			t.Logf("%+v", *f)
+		}
+		t.Fatal("unexpected normalized facts")
+	}
+}
+
+func TestRenderKnownFacts(t *testing.T) {
+	if got := renderKnownFacts(nil); got != "(none)" {
+		t.Fatalf("renderKnownFacts(nil) = %q", got)
+	}
+	got := renderKnownFacts([]*types.UserProfileFact{{Category: "role", Key: "job_title", Value: "SRE"}})
+	if got != "- role/job_title: SRE" {
+		t.Fatalf("renderKnownFacts = %q", got)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/Tencent/WeKnora/internal/utils"
@@ -15,28 +17,40 @@ import (
 
 // MemoryService implements the MemoryService interface
 type MemoryService struct {
-	repo         interfaces.MemoryRepository
-	modelService interfaces.ModelService
+	repo          interfaces.MemoryRepository
+	modelService  interfaces.ModelService
+	messageRepo   interfaces.MessageRepository
+	tenantService interfaces.TenantService
 }
 
 // NewMemoryService creates a new memory service
-func NewMemoryService(repo interfaces.MemoryRepository, modelService interfaces.ModelService) interfaces.MemoryService {
+func NewMemoryService(
+	repo interfaces.MemoryRepository,
+	modelService interfaces.ModelService,
+	messageRepo interfaces.MessageRepository,
+	tenantService interfaces.TenantService,
+) interfaces.MemoryService {
 	return &MemoryService{
-		repo:         repo,
-		modelService: modelService,
+		repo:          repo,
+		modelService:  modelService,
+		messageRepo:   messageRepo,
+		tenantService: tenantService,
 	}
 }
 
+// extractGraphPrompt is the built-in extraction prompt; tenants may replace
+// it through their memory extraction config.
 const extractGraphPrompt = `
 You are an AI assistant that extracts knowledge graphs from conversations.
 Given the following conversation, extract entities and relationships.
 Output the result in JSON format with the following structure:
 {
   "summary": "A brief summary of the conversation",
+  "salience": 0.5,
   "entities": [
     {
       "title": "Entity Name",
-      "type": "Entity Type (e.g., Person, Location, Concept)",
+      "type": "Entity Type",
       "description": "Description of the entity"
     }
   ],
@@ -47,11 +61,34 @@ Output the result in JSON format with the following structure:
       "description": "Description of the relationship",
       "weight": 1.0
     }
+  ],
+  "profile_facts": [
+    {
+      "category": "preference",
+      "key": "preferred_language",
+      "value": "Go"
+    }
   ]
 }
 
+"salience" rates, from 0 to 1, how useful the conversation will be to remember
+in the long run: stable facts, preferences and decisions about the user rate
+high, small talk and one-off questions rate low.
+
+"profile_facts" lists stable facts the user states about themselves: their
+name, preferences, role or the projects they work on. "category" is one of
+name, preference, role, project or other; "key" is a short snake_case
+identifier of the fact. Reuse the key of a known fact to update it, and give
+an empty "value" when the user says a known fact no longer holds. Leave the
+list empty when nothing new is learned about the user.
+
+Type each entity as {{entity_types}}.
+
+Known profile facts:
+{{known_facts}}
+
 Conversation:
-%s
+{{conversation}}
 `
 
 const extractKeywordsPrompt = `
@@ -66,47 +103,100 @@ Query:
 %s
 `
 
+// retrieveEpisodeLimit is the number of episodes RetrieveMemory returns.
+const retrieveEpisodeLimit = 5
+
 type extractionResult struct {
 	Summary       string                `json:"summary" jsonschema:"a brief summary of the conversation"`
+	Salience      float64               `json:"salience" jsonschema:"how useful the conversation is to remember long-term, from 0 to 1"`
 	Entities      []*types.Entity       `json:"entities"`
 	Relationships []*types.Relationship `json:"relationships"`
+	ProfileFacts  []profileFactResult   `json:"profile_facts"`
 }
 
 type keywordsResult struct {
 	Keywords []string `json:"keywords" jsonschema:"relevant keywords for searching a knowledge graph"`
 }
 
+// getChatModel returns the chat model memory runs on for the tenant.
 func (s *MemoryService) getChatModel(ctx context.Context) (chat.Chat, error) {
-	// Find the first available KnowledgeQA model
-	models, err := s.modelService.ListModels(ctx)
+	return memoryChatModel(ctx, s.modelService, s.extractionConfig(ctx))
+}
+
+// getEmbedder returns the tenant's first embedding model and its ID.
+func (s *MemoryService) getEmbedder(ctx context.Context) (string, embedding.Embedder, error) {
+	modelID, err := firstModelOfType(ctx, s.modelService, types.ModelTypeEmbedding)
+	if err != nil {
+		return "", nil, err
+	}
+	embedder, err := s.modelService.GetEmbeddingModel(ctx, modelID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to list models: %v", err)
+		return "", nil, err
 	}
+	return modelID, embedder, nil
+}
 
-	var modelID string
-	for _, model := range models {
-		if model.Type == types.ModelTypeKnowledgeQA {
-			modelID = model.ID
-			break
+// embedEpisode embeds the episode summary and the descriptions of its
+// entities. Semantic retrieval is optional: without an embedding model, or
+// when embedding fails, the episode is stored for keyword retrieval only.
+func (s *MemoryService) embedEpisode(ctx context.Context, summary string, entities []*types.Entity) *types.MemoryEmbeddings {
+	modelID, embedder, err := s.getEmbedder(ctx)
+	if err != nil {
+		logger.Infof(ctx, "memory episode stored without embeddings: %v", err)
+		return nil
+	}
+
+	texts := []string{summary}
+	for _, entity := range entities {
+		text := entity.Title
+		if entity.Description != "" {
+			text += ": " + entity.Description
 		}
+		texts = append(texts, text)
+	}
+	vectors, err := embedder.BatchEmbed(ctx, texts)
+	if err != nil || len(vectors) != len(texts) {
+		logger.Warnf(ctx, "failed to embed memory episode: %v", err)
+		return nil
 	}
 
-	if modelID == "" {
-		return nil, fmt.Errorf("no KnowledgeQA model found")
+	embeddings := &types.MemoryEmbeddings{
+		ModelID:  modelID,
+		Summary:  vectors[0],
+		Entities: make(map[string][]float32, len(entities)),
+	}
+	for i, entity := range entities {
+		embeddings.Entities[entity.Title] = vectors[i+1]
+	}
+	return embeddings
+}
+
+// firstModelOfType returns the ID of the tenant's first model of type t.
+func firstModelOfType(ctx context.Context, modelService interfaces.ModelService, t types.ModelType) (string, error) {
+	models, err := modelService.ListModels(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list models: %v", err)
+	}
+
+	for _, model := range models {
+		if model.Type == t {
+			return model.ID, nil
+		}
 	}
 
-	return s.modelService.GetChatModel(ctx, modelID)
+	return "", fmt.Errorf("no %s model found", t)
 }
 
 // AddEpisode adds a new episode to the memory graph
 func (s *MemoryService) AddEpisode(ctx context.Context, userID string, sessionID string, messages []types.Message) error {
 	if !s.repo.IsAvailable(ctx) {
-		return fmt.Errorf("memory repository is not available")
+		return types.ErrMemoryUnavailable
 	}
 	chatModel, err := s.getChatModel(ctx)
 	if err != nil {
 		return err
 	}
+	tenantID, _ := types.TenantIDFromContext(ctx)
 
 	// 1. Construct conversation string
 	var conversation string
@@ -114,10 +204,15 @@ func (s *MemoryService) AddEpisode(ctx context.Context, userID string, sessionID
 		conversation += fmt.Sprintf("%s: %s\n", msg.Role, msg.Content)
 	}
 
-	// 2. Call LLM to extract graph
-	prompt := fmt.Sprintf(extractGraphPrompt, conversation)
+	// 2. Call LLM to extract graph and profile updates
+	knownFacts, err := s.repo.GetProfileFacts(ctx, tenantID, userID)
+	if err != nil {
+		logger.Warnf(ctx, "failed to load user profile: %v", err)
+	}
+	config := s.extractionConfig(ctx)
+	prompt, format := buildExtractionRequest(config, renderKnownFacts(knownFacts), conversation)
 	resp, err := chatModel.Chat(ctx, []chat.Message{{Role: "user", Content: prompt}}, &chat.ChatOptions{
-		Format: utils.GenerateSchema[extractionResult](),
+		Format: format,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to call LLM: %v", err)
@@ -127,35 +222,132 @@ func (s *MemoryService) AddEpisode(ctx context.Context, userID string, sessionID
 	if err := json.Unmarshal([]byte(resp.Content), &result); err != nil {
 		return fmt.Errorf("failed to parse LLM response: %v", err)
 	}
+	for _, entity := range result.Entities {
+		if entity != nil {
+			entity.Type = config.NormalizeEntityType(entity.Type)
+		}
+	}
 
 	// 3. Create Episode object
 	episode := &types.Episode{
 		ID:        uuid.New().String(),
+		TenantID:  tenantID,
 		UserID:    userID,
 		SessionID: sessionID,
 		Summary:   result.Summary,
 		CreatedAt: time.Now(),
+		Salience:  clampSalience(result.Salience),
+	}
+	episode.Embeddings = s.embedEpisode(ctx, result.Summary, result.Entities)
+
+	// 4. Find the facts the episode contradicts. The check runs before
+	// saving so the episode's own relationships are not candidates.
+	contradicted, err := s.findContradictedRelationships(ctx, chatModel, tenantID, userID, result.Relationships)
+	if err != nil {
+		logger.Warnf(ctx, "failed to detect contradicted memory relationships: %v", err)
 	}
 
-	// 4. Save to repository
+	// 5. Save to repository, closing the contradicted relationships
 	if err := s.repo.SaveEpisode(ctx, episode, result.Entities, result.Relationships); err != nil {
 		return fmt.Errorf("failed to save episode: %v", err)
 	}
+	if err := s.repo.InvalidateRelationships(ctx, episode, contradicted); err != nil {
+		logger.Warnf(ctx, "failed to invalidate memory relationships: %v", err)
+	}
+
+	// 6. Update the user profile
+	if facts := normalizeProfileFacts(result.ProfileFacts); len(facts) > 0 {
+		if err := s.repo.SaveProfileFacts(ctx, tenantID, userID, episode.ID, facts); err != nil {
+			return fmt.Errorf("failed to save profile facts: %v", err)
+		}
+	}
 
 	return nil
 }
 
-// RetrieveMemory retrieves relevant memory context based on the current query and user
+// RetrieveMemory retrieves relevant memory context based on the current query and user.
+// Episodes are found by semantic search when the tenant has an embedding
+// model; keyword matching against entity names fills the remaining slots.
 func (s *MemoryService) RetrieveMemory(ctx context.Context, userID string, query string) (*types.MemoryContext, error) {
 	if !s.repo.IsAvailable(ctx) {
-		return nil, fmt.Errorf("memory repository is not available")
+		return nil, types.ErrMemoryUnavailable
 	}
+	tenantID, _ := types.TenantIDFromContext(ctx)
+
+	// 1. Semantic search
+	episodes := s.findSimilarEpisodes(ctx, tenantID, userID, query)
+
+	// 2. Keyword fallback
+	if len(episodes) < retrieveEpisodeLimit {
+		related, err := s.findEpisodesByKeywords(ctx, tenantID, userID, query)
+		if err != nil {
+			if len(episodes) == 0 {
+				return nil, err
+			}
+			logger.Warnf(ctx, "keyword memory retrieval failed: %v", err)
+		}
+		seen := make(map[string]bool, len(episodes))
+		for _, ep := range episodes {
+			seen[ep.ID] = true
+		}
+		for _, ep := range related {
+			if len(episodes) >= retrieveEpisodeLimit {
+				break
+			}
+			if !seen[ep.ID] {
+				seen[ep.ID] = true
+				episodes = append(episodes, ep)
+			}
+		}
+	}
+
+	// 3. Construct MemoryContext
+	memoryContext := &types.MemoryContext{
+		RelatedEpisodes: make([]types.Episode, len(episodes)),
+	}
+	ids := make([]string, len(episodes))
+	for i, ep := range episodes {
+		memoryContext.RelatedEpisodes[i] = *ep
+		ids[i] = ep.ID
+	}
+
+	// 4. Record the access, which keeps the episodes from being forgotten
+	if err := s.repo.RecordEpisodeAccess(ctx, tenantID, ids); err != nil {
+		logger.Warnf(ctx, "failed to record memory access: %v", err)
+	}
+
+	return memoryContext, nil
+}
+
+// findSimilarEpisodes embeds the query and searches the memory vector
+// indexes. It returns nothing when the tenant has no embedding model or
+// the search fails, leaving retrieval to keywords.
+func (s *MemoryService) findSimilarEpisodes(ctx context.Context, tenantID uint64, userID string, query string) []*types.Episode {
+	modelID, embedder, err := s.getEmbedder(ctx)
+	if err != nil {
+		return nil
+	}
+	vector, err := embedder.Embed(ctx, query)
+	if err != nil {
+		logger.Warnf(ctx, "failed to embed memory query: %v", err)
+		return nil
+	}
+	episodes, err := s.repo.FindSimilarEpisodes(ctx, tenantID, userID, modelID, vector, retrieveEpisodeLimit)
+	if err != nil {
+		logger.Warnf(ctx, "semantic memory retrieval failed: %v", err)
+		return nil
+	}
+	return episodes
+}
+
+// findEpisodesByKeywords extracts keywords from the query with the chat
+// model and matches them against entity names and aliases.
+func (s *MemoryService) findEpisodesByKeywords(ctx context.Context, tenantID uint64, userID string, query string) ([]*types.Episode, error) {
 	chatModel, err := s.getChatModel(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	// 1. Extract keywords
 	prompt := fmt.Sprintf(extractKeywordsPrompt, query)
 	resp, err := chatModel.Chat(ctx, []chat.Message{{Role: "user", Content: prompt}}, &chat.ChatOptions{
 		Format: utils.GenerateSchema[keywordsResult](),
@@ -169,19 +361,9 @@ func (s *MemoryService) RetrieveMemory(ctx context.Context, userID string, query
 		return nil, fmt.Errorf("failed to parse LLM response: %v", err)
 	}
 
-	// 2. Retrieve related episodes
-	episodes, err := s.repo.FindRelatedEpisodes(ctx, userID, result.Keywords, 5)
+	episodes, err := s.repo.FindRelatedEpisodes(ctx, tenantID, userID, result.Keywords, time.Time{}, retrieveEpisodeLimit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to find related episodes: %v", err)
 	}
-
-	// 3. Construct MemoryContext
-	memoryContext := &types.MemoryContext{
-		RelatedEpisodes: make([]types.Episode, len(episodes)),
-	}
-	for i, ep := range episodes {
-		memoryContext.RelatedEpisodes[i] = *ep
-	}
-
-	return memoryContext, nil
+	return episodes, nil
 }
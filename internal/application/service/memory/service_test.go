@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+type fakeMemoryRepo struct {
+	interfaces.MemoryRepository
+	similar  []*types.Episode
+	related  []*types.Episode
+	keywords []string
+	accessed []string
+}
+
+func (r *fakeMemoryRepo) IsAvailable(context.Context) bool { return true }
+
+func (r *fakeMemoryRepo) FindSimilarEpisodes(_ context.Context, _ uint64, _, modelID string, _ []float32, _ int) ([]*types.Episode, error) {
+	if modelID != "emb" {
+		return nil, nil
+	}
+	return r.similar, nil
+}
+
+func (r *fakeMemoryRepo) RecordEpisodeAccess(_ context.Context, _ uint64, ids []string) error {
+	r.accessed = append(r.accessed, ids...)
+	return nil
+}
+
+func (r *fakeMemoryRepo) FindRelatedEpisodes(_ context.Context, _ uint64, _ string, keywords []string, _ time.Time, _ int) ([]*types.Episode, error) {
+	r.keywords = keywords
+	return r.related, nil
+}
+
+type fakeModelService struct {
+	interfaces.ModelService
+	models []*types.Model
+}
+
+func (s *fakeModelService) ListModels(context.Context) ([]*types.Model, error) { return s.models, nil }
+
+func (s *fakeModelService) GetEmbeddingModel(context.Context, string) (embedding.Embedder, error) {
+	return fakeEmbedder{}, nil
+}
+
+func (s *fakeModelService) GetChatModel(context.Context, string) (chat.Chat, error) {
+	return fakeChat{}, nil
+}
+
+type fakeEmbedder struct{ embedding.Embedder }
+
+func (fakeEmbedder) Embed(context.Context, string) ([]float32, error) { return []float32{1, 0}, nil }
+
+type fakeChat struct{}
+
+func (fakeChat) ChatStream(context.Context, []chat.Message, *chat.ChatOptions) (<-chan types.StreamResponse, error) {
+	return nil, nil
+}
+func (fakeChat) GetModelName() string { return "fake" }
+func (fakeChat) GetModelID() string   { return "qa" }
+
+func (fakeChat) Chat(context.Context, []chat.Message, *chat.ChatOptions) (*types.ChatResponse, error) {
+	return &types.ChatResponse{Content: `{"keywords": ["Tencent"]}`}, nil
+}
+
+func episodeIDs(episodes []types.Episode) []string {
+	var ids []string
+	for _, ep := range episodes {
+		ids = append(ids, ep.ID)
+	}
+	return ids
+}
+
+func TestRetrieveMemoryPrefersSemanticMatches(t *testing.T) {
+	repo := &fakeMemoryRepo{
+		similar: []*types.Episode{{ID: "a"}, {ID: "b"}},
+		related: []*types.Episode{{ID: "b"}, {ID: "c"}},
+	}
+	models := &fakeModelService{models: []*types.Model{
+		{ID: "qa", Type: types.ModelTypeKnowledgeQA},
+		{ID: "emb", Type: types.ModelTypeEmbedding},
+	}}
+	svc := NewMemoryService(repo, models, nil, nil)
+
+	got, err := svc.RetrieveMemory(context.Background(), "u1", "what did we say about Tencent?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := episodeIDs(got.RelatedEpisodes); !reflect.DeepEqual(ids, []string{"a", "b", "c"}) {
+		t.Fatalf("episodes = %v, want semantic matches first, keyword matches appended", ids)
+	}
+	if !reflect.DeepEqual(repo.accessed, []string{"a", "b", "c"}) {
+		t.Fatalf("accessed = %v, want every returned episode", repo.accessed)
+	}
+
+	// Without an embedding model retrieval falls back to keywords only.
+	models.models = models.models[:1]
+	got, err = svc.RetrieveMemory(context.Background(), "u1", "Tencent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := episodeIDs(got.RelatedEpisodes); !reflect.DeepEqual(ids, []string{"b", "c"}) {
+		t.Fatalf("episodes = %v, want keyword matches", ids)
+	}
+	if !reflect.DeepEqual(repo.keywords, []string{"Tencent"}) {
+		t.Fatalf("keywords = %v", repo.keywords)
+	}
+}
@@ -0,0 +1,108 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+// maxContradictionCandidates bounds the existing relationships checked
+// against a new episode.
+const maxContradictionCandidates = 50
+
+const detectContradictionsPrompt = `
+You are an AI assistant that maintains a knowledge graph about a user over time.
+Below are facts currently recorded in the graph, and new facts learned from the
+latest conversation. List the existing facts that the new facts contradict,
+that is facts that can no longer be true now, for example because the user
+changed jobs, moved or changed their mind. A fact that is merely different,
+more specific or unrelated is not contradicted.
+Output the result in JSON format:
+{
+  "contradicted": [0, 2]
+}
+
+Existing facts:
+%s
+
+New facts:
+%s
+`
+
+type contradictionResult struct {
+	Contradicted []int `json:"contradicted" jsonschema:"indexes of the existing facts contradicted by the new facts"`
+}
+
+// findContradictedRelationships returns the IDs of the user's currently
+// valid relationships that the newly extracted ones contradict. Existing
+// relationships restated by the episode are never candidates.
+func (s *MemoryService) findContradictedRelationships(ctx context.Context, chatModel chat.Chat,
+	tenantID uint64, userID string, relations []*types.Relationship,
+) ([]string, error) {
+	if len(relations) == 0 {
+		return nil, nil
+	}
+	var names []string
+	restated := make(map[string]bool, len(relations))
+	for _, rel := range relations {
+		names = append(names, rel.Source, rel.Target)
+		restated[relationshipKey(rel.Source, rel.Target, rel.Description)] = true
+	}
+	existing, err := s.repo.ListActiveRelationships(ctx, tenantID, userID, names, maxContradictionCandidates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list relationships: %v", err)
+	}
+	var candidates []*types.MemoryRelationship
+	for _, rel := range existing {
+		if !restated[relationshipKey(rel.Source, rel.Target, rel.Description)] {
+			candidates = append(candidates, rel)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	var existingFacts, newFacts strings.Builder
+	for i, rel := range candidates {
+		fmt.Fprintf(&existingFacts, "[%d] %s -> %s: %s", i, rel.Source, rel.Target, rel.Description)
+		if !rel.ValidFrom.IsZero() {
+			fmt.Fprintf(&existingFacts, " (since %s)", rel.ValidFrom.Format("2006-01-02"))
+		}
+		existingFacts.WriteString("\n")
+	}
+	for _, rel := range relations {
+		fmt.Fprintf(&newFacts, "- %s -> %s: %s\n", rel.Source, rel.Target, rel.Description)
+	}
+
+	prompt := fmt.Sprintf(detectContradictionsPrompt, existingFacts.String(), newFacts.String())
+	resp, err := chatModel.Chat(ctx, []chat.Message{{Role: "user", Content: prompt}}, &chat.ChatOptions{
+		Format: utils.GenerateSchema[contradictionResult](),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to call LLM: %v", err)
+	}
+	var result contradictionResult
+	if err := json.Unmarshal([]byte(resp.Content), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse LLM response: %v", err)
+	}
+
+	var ids []string
+	seen := make(map[int]bool, len(result.Contradicted))
+	for _, i := range result.Contradicted {
+		if i < 0 || i >= len(candidates) || seen[i] {
+			continue
+		}
+		seen[i] = true
+		ids = append(ids, candidates[i].ID)
+	}
+	return ids, nil
+}
+
+func relationshipKey(source, target, description string) string {
+	return strings.ToLower(source) + "\x00" + strings.ToLower(target) + "\x00" + strings.ToLower(description)
+}
@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+type relationshipRepo struct {
+	interfaces.MemoryRepository
+	active []*types.MemoryRelationship
+}
+
+func (r *relationshipRepo) ListActiveRelationships(context.Context, uint64, string, []string, int) ([]*types.MemoryRelationship, error) {
+	return r.active, nil
+}
+
+type scriptedChat struct {
+	fakeChat
+	content string
+	prompts []string
+}
+
+func (c *scriptedChat) Chat(_ context.Context, messages []chat.Message, _ *chat.ChatOptions) (*types.ChatResponse, error) {
+	c.prompts = append(c.prompts, messages[0].Content)
+	return &types.ChatResponse{Content: c.content}, nil
+}
+
+func TestFindContradictedRelationships(t *testing.T) {
+	repo := &relationshipRepo{active: []*types.MemoryRelationship{
+		{ID: "r0", Source: "Lin", Target: "Tencent", Description: "works at"},
+		{ID: "r1", Source: "Lin", Target: "Go", Description: "likes"},
+		{ID: "r2", Source: "Lin", Target: "Shenzhen", Description: "lives in"},
+	}}
+	svc := &MemoryService{repo: repo}
+	model := &scriptedChat{content: `{"contradicted": [1, 0, 7, 0]}`}
+
+	ids, err := svc.findContradictedRelationships(context.Background(), model, 1, "u1", []*types.Relationship{
+		{Source: "Lin", Target: "Alibaba", Description: "works at"},
+		{Source: "Lin", Target: "Go", Description: "Likes"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "likes Go" is restated, so it is not offered as a candidate and
+	// index 1 is "lives in Shenzhen"; out of range and repeated indexes
+	// are ignored.
+	if want := []string{"r2", "r0"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("contradicted = %v, want %v", ids, want)
+	}
+
+	// Without candidates the model is not consulted.
+	model.prompts = nil
+	repo.active = repo.active[1:2]
+	ids, err = svc.findContradictedRelationships(context.Background(), model, 1, "u1", []*types.Relationship{
+		{Source: "Lin", Target: "Go", Description: "likes"},
+	})
+	if err != nil || len(ids) != 0 || len(model.prompts) != 0 {
+		t.Fatalf("got %v, %v after %d prompts, want no contradictions and no prompt", ids, err, len(model.prompts))
+	}
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// sessionEpisodeMaxTurns bounds the turns stored as one episode when a
+// session ends; earlier turns live on in the session's rolling summary.
+const sessionEpisodeMaxTurns = 20
+
+const memorablePrompt = `
+Decide whether the following turn of a conversation between a user and an AI
+assistant is worth remembering in later conversations. Remember turns that
+reveal the user's goals, decisions, preferences, plans or facts about the user
+and the things they work with. Do not remember greetings, small talk, or
+one-off questions whose answers are of no later use.
+
+Answer with "yes" or "no" only.
+
+Conversation:
+%s
+`
+
+// IsMemorable asks the chat model whether the turn is worth storing as an
+// episode.
+func (s *MemoryService) IsMemorable(ctx context.Context, messages []types.Message) (bool, error) {
+	chatModel, err := s.getChatModel(ctx)
+	if err != nil {
+		return false, err
+	}
+	var conversation strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&conversation, "%s: %s\n", msg.Role, msg.Content)
+	}
+	thinking := false
+	resp, err := chatModel.Chat(ctx, []chat.Message{
+		{Role: "user", Content: fmt.Sprintf(memorablePrompt, conversation.String())},
+	}, &chat.ChatOptions{Temperature: 0, MaxCompletionTokens: 5, Thinking: &thinking})
+	if err != nil {
+		return false, fmt.Errorf("failed to call LLM: %v", err)
+	}
+	answer := strings.ToLower(strings.TrimSpace(thinkTags.ReplaceAllString(resp.Content, "")))
+	return strings.HasPrefix(answer, "yes"), nil
+}
+
+// CountSessionTurns returns how many questions have been asked in the
+// session.
+func (s *MemoryService) CountSessionTurns(ctx context.Context, sessionID string) (int, error) {
+	n, err := s.messageRepo.CountUserMessagesBySession(ctx, sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count session turns: %v", err)
+	}
+	return int(n), nil
+}
+
+// AddSessionEpisode stores the latest answered turns of the session as one
+// episode, at most sessionEpisodeMaxTurns of them; turns of 0 takes as many
+// as allowed.
+func (s *MemoryService) AddSessionEpisode(ctx context.Context, userID string, sessionID string, turns int) error {
+	if !s.repo.IsAvailable(ctx) {
+		return types.ErrMemoryUnavailable
+	}
+	if turns <= 0 || turns > sessionEpisodeMaxTurns {
+		turns = sessionEpisodeMaxTurns
+	}
+	// Fetch a little more than the turns' messages so an unanswered question
+	// at the end does not crowd out an answered one.
+	recent, err := s.messageRepo.GetRecentMessagesBySession(ctx, sessionID, 2*turns+2)
+	if err != nil {
+		return fmt.Errorf("failed to load session messages: %v", err)
+	}
+	messages := latestTurns(recent, turns)
+	if len(messages) == 0 {
+		return nil
+	}
+	return s.AddEpisode(ctx, userID, sessionID, messages)
+}
+
+// latestTurns returns the messages of the latest n answered turns, oldest
+// first, without reasoning blocks.
+func latestTurns(messages []*types.Message, n int) []types.Message {
+	unanswered := map[string]bool{}
+	for _, msg := range messages {
+		if !msg.IsCompleted {
+			unanswered[msg.RequestID] = true
+		}
+	}
+	var out []types.Message
+	requests := map[string]bool{}
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if unanswered[msg.RequestID] {
+			continue
+		}
+		if !requests[msg.RequestID] {
+			if len(requests) == n {
+				break
+			}
+			requests[msg.RequestID] = true
+		}
+		content := strings.TrimSpace(thinkTags.ReplaceAllString(msg.Content, ""))
+		if content == "" {
+			continue
+		}
+		out = append(out, types.Message{Role: msg.Role, Content: content})
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestLatestTurns(t *testing.T) {
+	start := time.Now().Add(-time.Hour)
+	var messages []*types.Message
+	for i, id := range []string{"r1", "r2", "r3"} {
+		messages = append(messages, turn(id, start.Add(time.Duration(i)*time.Minute), 10)...)
+	}
+	// A question still being answered is left out.
+	messages = append(messages,
+		&types.Message{RequestID: "r4", Role: "user", Content: "pending", CreatedAt: start.Add(time.Hour), IsCompleted: true},
+		&types.Message{RequestID: "r4", Role: "assistant", CreatedAt: start.Add(time.Hour)},
+	)
+
+	got := latestTurns(messages, 2)
+	if len(got) != 4 {
+		t.Fatalf("got %d messages, want the 4 of r2 and r3", len(got))
+	}
+	if got[0].Role != "user" || got[3].Role != "assistant" {
+		t.Fatalf("messages out of order: %+v", got)
+	}
+	if n := len(latestTurns(messages, 10)); n != 6 {
+		t.Fatalf("got %d messages, want all 6 answered ones", n)
+	}
+}
+
+type countingMessages struct {
+	sessionMessages
+	count int64
+}
+
+func (r *countingMessages) CountUserMessagesBySession(context.Context, string) (int64, error) {
+	return r.count, nil
+}
+
+func TestCountSessionTurns(t *testing.T) {
+	svc := &MemoryService{messageRepo: &countingMessages{count: 3}}
+	if n, err := svc.CountSessionTurns(context.Background(), "s1"); err != nil || n != 3 {
+		t.Fatalf("CountSessionTurns = %d, %v", n, err)
+	}
+}
+
+func TestIsMemorable(t *testing.T) {
+	model := &scriptedChat{content: "<think>a stated preference</think> Yes."}
+	svc := &MemoryService{modelService: &summaryModels{
+		fakeModelService: fakeModelService{models: []*types.Model{{ID: "qa", Type: types.ModelTypeKnowledgeQA}}},
+		chat:             model,
+	}}
+	turn := []types.Message{{Role: "user", Content: "I prefer Go"}, {Role: "assistant", Content: "Noted"}}
+
+	if ok, err := svc.IsMemorable(context.Background(), turn); err != nil || !ok {
+		t.Fatalf("IsMemorable = %v, %v, want true", ok, err)
+	}
+	model.content = "no"
+	if ok, err := svc.IsMemorable(context.Background(), turn); err != nil || ok {
+		t.Fatalf("IsMemorable = %v, %v, want false", ok, err)
+	}
+}
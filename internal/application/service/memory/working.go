@@ -0,0 +1,189 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	envWorkingMemoryBudget = "MEMORY_WORKING_BUDGET_CHARS"
+	// defaultWorkingMemoryBudget is how many characters of a session's
+	// messages may stay unsummarized before the oldest are folded into the
+	// rolling summary.
+	defaultWorkingMemoryBudget = 8000
+	// workingMemoryFetchLimit bounds the messages read per update; older
+	// unsummarized messages are picked up by later updates.
+	workingMemoryFetchLimit = 200
+	// maxSessionSummaryLength bounds the rolling summary, which is injected
+	// into every prompt of the session.
+	maxSessionSummaryLength = 2000
+)
+
+const summarizeSessionPrompt = `
+You maintain the running summary of a long conversation between a user and an
+AI assistant. Older messages no longer fit the assistant's context, so the
+summary is all it will remember of them.
+
+Update the summary with the new messages below. Keep the user's goals,
+questions, decisions, constraints and any facts or answers that later turns may
+refer back to; drop greetings and repetition. Write in the language of the
+conversation, in at most %d characters. Output only the updated summary.
+
+Current summary:
+%s
+
+New messages:
+%s
+`
+
+var thinkTags = regexp.MustCompile(`(?s)<think>.*?</think>`)
+
+// workingMemoryBudget reads MEMORY_WORKING_BUDGET_CHARS, keeping the default
+// for unset or invalid values.
+func workingMemoryBudget() int {
+	if n, err := strconv.Atoi(strings.TrimSpace(os.Getenv(envWorkingMemoryBudget))); err == nil && n > 0 {
+		return n
+	}
+	return defaultWorkingMemoryBudget
+}
+
+// selectOverflow returns how many of the oldest messages to fold into the
+// summary. Nothing is folded while the messages fit the budget; past it,
+// the oldest are folded until half the budget remains, so summarization
+// runs every few turns rather than on each one. A question is never split
+// from its answer.
+func selectOverflow(messages []*types.Message, budget int) int {
+	total := 0
+	for _, msg := range messages {
+		total += len([]rune(msg.Content))
+	}
+	if total <= budget {
+		return 0
+	}
+	n := 0
+	for n < len(messages) && total > budget/2 {
+		total -= len([]rune(messages[n].Content))
+		n++
+	}
+	for n < len(messages) && messages[n].RequestID != "" && messages[n].RequestID == messages[n-1].RequestID {
+		n++
+	}
+	return n
+}
+
+// GetSessionSummary returns the rolling summary of the user's session, or
+// nil when nothing has been summarized yet.
+func (s *MemoryService) GetSessionSummary(ctx context.Context, userID string, sessionID string) (*types.SessionSummary, error) {
+	if !s.repo.IsAvailable(ctx) {
+		return nil, types.ErrMemoryUnavailable
+	}
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	summary, err := s.repo.GetSessionSummary(ctx, tenantID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session summary: %v", err)
+	}
+	if summary == nil || summary.UserID != userID {
+		return nil, nil
+	}
+	return summary, nil
+}
+
+// UpdateSessionSummary folds the oldest unsummarized messages of the session
+// into its rolling summary once they exceed the working memory budget.
+func (s *MemoryService) UpdateSessionSummary(ctx context.Context, userID string, sessionID string) error {
+	if !s.repo.IsAvailable(ctx) {
+		return types.ErrMemoryUnavailable
+	}
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	current, err := s.repo.GetSessionSummary(ctx, tenantID, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session summary: %v", err)
+	}
+	if current == nil {
+		current = &types.SessionSummary{TenantID: tenantID, SessionID: sessionID, UserID: userID}
+	}
+
+	// 1. Collect the messages not yet summarized, up to the question whose
+	// answer is still being generated
+	recent, err := s.messageRepo.GetRecentMessagesBySession(ctx, sessionID, workingMemoryFetchLimit)
+	if err != nil {
+		return fmt.Errorf("failed to load session messages: %v", err)
+	}
+	var pending []*types.Message
+	for _, msg := range recent {
+		if !msg.CreatedAt.After(current.SummarizedUntil) {
+			continue
+		}
+		if !msg.IsCompleted {
+			for len(pending) > 0 && pending[len(pending)-1].RequestID == msg.RequestID {
+				pending = pending[:len(pending)-1]
+			}
+			break
+		}
+		content := strings.TrimSpace(thinkTags.ReplaceAllString(msg.Content, ""))
+		if content == "" {
+			continue
+		}
+		pending = append(pending, &types.Message{
+			RequestID: msg.RequestID,
+			Role:      msg.Role,
+			Content:   content,
+			CreatedAt: msg.CreatedAt,
+		})
+	}
+
+	// 2. Select the overflow
+	n := selectOverflow(pending, workingMemoryBudget())
+	if n == 0 {
+		return nil
+	}
+	overflow := pending[:n]
+
+	// 3. Fold it into the summary
+	chatModel, err := s.getChatModel(ctx)
+	if err != nil {
+		return err
+	}
+	var conversation strings.Builder
+	for _, msg := range overflow {
+		fmt.Fprintf(&conversation, "%s: %s\n", msg.Role, msg.Content)
+	}
+	previous := current.Summary
+	if previous == "" {
+		previous = "(none)"
+	}
+	prompt := fmt.Sprintf(summarizeSessionPrompt, maxSessionSummaryLength, previous, conversation.String())
+	resp, err := chatModel.Chat(ctx, []chat.Message{{Role: "user", Content: prompt}}, &chat.ChatOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to call LLM: %v", err)
+	}
+	summary := strings.TrimSpace(thinkTags.ReplaceAllString(resp.Content, ""))
+	if summary == "" {
+		return fmt.Errorf("LLM returned an empty session summary")
+	}
+	if runes := []rune(summary); len(runes) > maxSessionSummaryLength {
+		summary = string(runes[:maxSessionSummaryLength])
+	}
+
+	// 4. Save it
+	current.UserID = userID
+	current.Summary = summary
+	current.SummarizedUntil = overflow[n-1].CreatedAt
+	current.MessageCount += n
+	current.UpdatedAt = time.Now()
+	if err := s.repo.SaveSessionSummary(ctx, current); err != nil {
+		return fmt.Errorf("failed to save session summary: %v", err)
+	}
+	logger.Infof(ctx, "session %s working memory: folded %d messages, %d summarized in total",
+		sessionID, n, current.MessageCount)
+	return nil
+}
@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+type summaryRepo struct {
+	interfaces.MemoryRepository
+	summary *types.SessionSummary
+}
+
+func (r *summaryRepo) IsAvailable(context.Context) bool { return true }
+
+func (r *summaryRepo) GetSessionSummary(context.Context, uint64, string) (*types.SessionSummary, error) {
+	return r.summary, nil
+}
+
+func (r *summaryRepo) SaveSessionSummary(_ context.Context, summary *types.SessionSummary) error {
+	r.summary = summary
+	return nil
+}
+
+type sessionMessages struct {
+	interfaces.MessageRepository
+	messages []*types.Message
+}
+
+func (r *sessionMessages) GetRecentMessagesBySession(context.Context, string, int) ([]*types.Message, error) {
+	return r.messages, nil
+}
+
+type summaryModels struct {
+	fakeModelService
+	chat *scriptedChat
+}
+
+func (s *summaryModels) GetChatModel(context.Context, string) (chat.Chat, error) { return s.chat, nil }
+
+// turn returns a completed question and answer of n characters each.
+func turn(id string, at time.Time, n int) []*types.Message {
+	return []*types.Message{
+		{RequestID: id, Role: "user", Content: strings.Repeat("q", n), CreatedAt: at, IsCompleted: true},
+		{RequestID: id, Role: "assistant", Content: strings.Repeat("a", n), CreatedAt: at.Add(time.Second), IsCompleted: true},
+	}
+}
+
+func TestSelectOverflow(t *testing.T) {
+	now := time.Now()
+	var messages []*types.Message
+	for i, id := range []string{"r1", "r2", "r3", "r4"} {
+		messages = append(messages, turn(id, now.Add(time.Duration(i)*time.Minute), 100)...)
+	}
+
+	if n := selectOverflow(messages, 800); n != 0 {
+		t.Fatalf("messages within budget: folded %d, want 0", n)
+	}
+	// 800 characters over a budget of 700 fold down to 350: r1, r2 and the
+	// question of r3, which takes its answer along.
+	if n := selectOverflow(messages, 700); n != 6 {
+		t.Fatalf("folded %d messages, want 6", n)
+	}
+}
+
+func TestUpdateSessionSummary(t *testing.T) {
+	t.Setenv(envWorkingMemoryBudget, "500")
+	start := time.Now().Add(-time.Hour)
+	var messages []*types.Message
+	for i, id := range []string{"r1", "r2", "r3"} {
+		messages = append(messages, turn(id, start.Add(time.Duration(i)*time.Minute), 100)...)
+	}
+	repo := &summaryRepo{}
+	model := &scriptedChat{content: "<think>hmm</think>The user asked about q."}
+	svc := &MemoryService{
+		repo: repo,
+		modelService: &summaryModels{
+			fakeModelService: fakeModelService{models: []*types.Model{{ID: "qa", Type: types.ModelTypeKnowledgeQA}}},
+			chat:             model,
+		},
+		messageRepo: &sessionMessages{messages: messages},
+	}
+	ctx := context.Background()
+
+	if err := svc.UpdateSessionSummary(ctx, "u1", "s1"); err != nil {
+		t.Fatal(err)
+	}
+	got := repo.summary
+	if got == nil || got.Summary != "The user asked about q." || got.MessageCount != 4 {
+		t.Fatalf("summary = %+v, want r1 and r2 folded", got)
+	}
+	if !got.SummarizedUntil.Equal(messages[3].CreatedAt) {
+		t.Fatalf("summarized until %s, want the answer of r2", got.SummarizedUntil)
+	}
+
+	// The next turn is still being answered, so only r3 is unsummarized
+	// and fits the budget.
+	svc.messageRepo = &sessionMessages{messages: append(messages,
+		&types.Message{RequestID: "r4", Role: "user", Content: strings.Repeat("q", 400), CreatedAt: start.Add(time.Hour), IsCompleted: true},
+		&types.Message{RequestID: "r4", Role: "assistant", CreatedAt: start.Add(time.Hour), IsCompleted: false},
+	)}
+	if err := svc.UpdateSessionSummary(ctx, "u1", "s1"); err != nil {
+		t.Fatal(err)
+	}
+	if len(model.prompts) != 1 {
+		t.Fatalf("model prompted %d times, want 1", len(model.prompts))
+	}
+
+	if summary, _ := svc.GetSessionSummary(ctx, "u2", "s1"); summary != nil {
+		t.Fatalf("another user's session summary was returned: %+v", summary)
+	}
+}
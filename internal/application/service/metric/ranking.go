@@ -0,0 +1,28 @@
+package metric
+
+import (
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// RankingInput converts a ranked result list, given as the index of the
+// expected source each result matched or -1, into the input of the
+// retrieval metrics. The expected sources become the ground truth IDs
+// 0..expected-1; unmatched results get IDs past them so they count as misses.
+func RankingInput(matches []int, expected int) *types.MetricInput {
+	gt := make([]int, expected)
+	for i := range gt {
+		gt[i] = i
+	}
+	ids := make([]int, len(matches))
+	for i, m := range matches {
+		if m >= 0 && m < expected {
+			ids[i] = m
+		} else {
+			ids[i] = expected + i
+		}
+	}
+	return &types.MetricInput{
+		RetrievalGT:  [][]int{gt},
+		RetrievalIDs: ids,
+	}
+}
@@ -0,0 +1,61 @@
+package metric
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRankingInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		matches    []int
+		expected   int
+		wantRecall float64
+		wantRR     float64
+		wantNDCG   float64
+	}{
+		{
+			name:       "both sources on top",
+			matches:    []int{1, 0, -1},
+			expected:   2,
+			wantRecall: 1.0,
+			wantRR:     1.0,
+			wantNDCG:   1.0,
+		},
+		{
+			name:     "one source at rank two",
+			matches:  []int{-1, 0, -1},
+			expected: 2,
+			// recall = 1/2, RR = 1/2
+			wantRecall: 0.5,
+			wantRR:     0.5,
+			// DCG = 1/log2(3), IDCG = 1 + 1/log2(3)
+			wantNDCG: (1 / math.Log2(3)) / (1 + 1/math.Log2(3)),
+		},
+		{
+			name:     "no match",
+			matches:  []int{-1, -1},
+			expected: 1,
+		},
+		{
+			name:     "no results",
+			matches:  nil,
+			expected: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := RankingInput(tt.matches, tt.expected)
+			if got := NewRecallMetric().Compute(input); math.Abs(got-tt.wantRecall) > 1e-9 {
+				t.Errorf("recall = %v, want %v", got, tt.wantRecall)
+			}
+			if got := NewMRRMetric().Compute(input); math.Abs(got-tt.wantRR) > 1e-9 {
+				t.Errorf("reciprocal rank = %v, want %v", got, tt.wantRR)
+			}
+			if got := NewNDCGMetric(10).Compute(input); math.Abs(got-tt.wantNDCG) > 1e-9 {
+				t.Errorf("ndcg = %v, want %v", got, tt.wantNDCG)
+			}
+		})
+	}
+}
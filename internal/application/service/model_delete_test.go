@@ -50,6 +50,17 @@ func (s *stubKBRepoForModelDelete) SetUserKBPin(context.Context, uint64, string,
 func (s *stubKBRepoForModelDelete) ListUserKBPinIDs(context.Context, uint64, string) (map[string]time.Time, error) {
 	return nil, nil
 }
+func (s *stubKBRepoForModelDelete) BeginIndexGeneration(context.Context, string) (int64, error) {
+	return 0, nil
+}
+func (s *stubKBRepoForModelDelete) AbortIndexGeneration(context.Context, string, int64) error {
+	return nil
+}
+func (s *stubKBRepoForModelDelete) SwitchIndexGeneration(
+	context.Context, string, int64, []types.ChunkType, string,
+) ([]string, error) {
+	return nil, nil
+}
 
 type stubAgentRepoForModelDelete struct {
 	count int64
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+)
+
+// retentionPurgeBatch is how many sessions, messages or documents a run
+// deletes at a time. A run keeps going batch by batch until nothing past
+// the cutoff is left or its context ends.
+const retentionPurgeBatch = 200
+
+// retentionService implements RetentionService.
+type retentionService struct {
+	repo             interfaces.RetentionRepository
+	kbRepo           interfaces.KnowledgeBaseRepository
+	messageRepo      interfaces.MessageRepository
+	knowledgeService interfaces.KnowledgeService
+	memoryRepo       interfaces.MemoryRepository
+	audit            interfaces.AuditLogService // optional; nil ⇒ no audit, business ops still succeed
+}
+
+// NewRetentionService creates a new retention service.
+func NewRetentionService(
+	repo interfaces.RetentionRepository,
+	kbRepo interfaces.KnowledgeBaseRepository,
+	messageRepo interfaces.MessageRepository,
+	knowledgeService interfaces.KnowledgeService,
+	memoryRepo interfaces.MemoryRepository,
+	audit interfaces.AuditLogService,
+) interfaces.RetentionService {
+	return &retentionService{
+		repo:             repo,
+		kbRepo:           kbRepo,
+		messageRepo:      messageRepo,
+		knowledgeService: knowledgeService,
+		memoryRepo:       memoryRepo,
+		audit:            audit,
+	}
+}
+
+// GetTenantPolicy returns the tenant-wide retention policy.
+func (s *retentionService) GetTenantPolicy(ctx context.Context) (*types.RetentionPolicy, error) {
+	return s.getPolicy(ctx, types.MustTenantIDFromContext(ctx), "")
+}
+
+// UpdateTenantPolicy replaces the tenant-wide retention policy.
+func (s *retentionService) UpdateTenantPolicy(
+	ctx context.Context,
+	req *types.RetentionPolicyRequest,
+) (*types.RetentionPolicy, error) {
+	if req.KnowledgeRetentionDays != 0 || req.KnowledgeExpiresAt != nil {
+		return nil, werrors.NewBadRequestError("文档保留期需在知识库的保留策略中设置")
+	}
+	if err := validateRetentionDays(req.SessionRetentionDays, req.MemoryRetentionDays); err != nil {
+		return nil, err
+	}
+	current, err := s.GetTenantPolicy(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.savePolicy(ctx, current, req)
+}
+
+// GetKnowledgeBasePolicy returns the retention policy of a knowledge base.
+func (s *retentionService) GetKnowledgeBasePolicy(ctx context.Context, kbID string) (*types.RetentionPolicy, error) {
+	kb, err := s.ownKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	return s.getPolicy(ctx, kb.TenantID, kb.ID)
+}
+
+// UpdateKnowledgeBasePolicy replaces the retention policy of a knowledge base.
+func (s *retentionService) UpdateKnowledgeBasePolicy(
+	ctx context.Context,
+	kbID string,
+	req *types.RetentionPolicyRequest,
+) (*types.RetentionPolicy, error) {
+	if req.SessionRetentionDays != 0 || req.MemoryRetentionDays != 0 {
+		return nil, werrors.NewBadRequestError("会话与记忆保留期需在租户的保留策略中设置")
+	}
+	if err := validateRetentionDays(req.KnowledgeRetentionDays); err != nil {
+		return nil, err
+	}
+	current, err := s.GetKnowledgeBasePolicy(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	return s.savePolicy(ctx, current, req)
+}
+
+// PreviewPolicies reports what each policy of the tenant would delete now.
+// Disabled policies are previewed too, so a policy can be checked before it
+// is switched on.
+func (s *retentionService) PreviewPolicies(ctx context.Context) ([]*types.RetentionReport, error) {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	policies, err := s.repo.ListPolicies(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]*types.RetentionReport, 0, len(policies))
+	for _, policy := range policies {
+		report, err := s.run(ctx, policy, true)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// ApplyPolicy runs a retention policy and records what it deleted.
+func (s *retentionService) ApplyPolicy(
+	ctx context.Context,
+	policy *types.RetentionPolicy,
+) (*types.RetentionReport, error) {
+	report, err := s.run(ctx, policy, false)
+	if report != nil && report.Sessions+report.Messages+report.Episodes+report.Knowledge > 0 {
+		logger.Infof(ctx, "Retention policy %s of tenant %d deleted %d sessions, %d messages, %d episodes, %d documents",
+			policy.ID, policy.TenantID, report.Sessions, report.Messages, report.Episodes, report.Knowledge)
+		s.emitAudit(ctx, policy, types.AuditActionRetentionPurged, "", report)
+	}
+	return report, err
+}
+
+// run applies or previews a policy. On error the report holds what was
+// deleted before it.
+func (s *retentionService) run(
+	ctx context.Context,
+	policy *types.RetentionPolicy,
+	dryRun bool,
+) (*types.RetentionReport, error) {
+	now := time.Now()
+	report := &types.RetentionReport{
+		PolicyID:        policy.ID,
+		KnowledgeBaseID: policy.KnowledgeBaseID,
+		Enabled:         policy.Enabled,
+		DryRun:          dryRun,
+	}
+	if policy.IsTenantWide() {
+		report.SessionCutoff = policy.SessionCutoff(now)
+		report.MemoryCutoff = policy.MemoryCutoff(now)
+		if err := s.runSessions(ctx, policy.TenantID, report, dryRun); err != nil {
+			return report, err
+		}
+		if err := s.runMemory(ctx, policy.TenantID, report, dryRun); err != nil {
+			return report, err
+		}
+		return report, nil
+	}
+	report.KnowledgeCutoff = policy.KnowledgeCutoff(now)
+	if err := s.runKnowledge(ctx, policy, report, dryRun); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// runSessions deletes the sessions idle since before the cutoff, with their
+// messages and the chat history documents indexed from them, then the
+// older messages of the sessions still in use and the knowledge gaps last
+// seen before the cutoff.
+func (s *retentionService) runSessions(
+	ctx context.Context,
+	tenantID uint64,
+	report *types.RetentionReport,
+	dryRun bool,
+) error {
+	if report.SessionCutoff == nil {
+		return nil
+	}
+	cutoff := *report.SessionCutoff
+	if dryRun {
+		sessions, err := s.repo.CountIdleSessions(ctx, tenantID, cutoff)
+		if err != nil {
+			return err
+		}
+		messages, err := s.repo.CountOldMessages(ctx, tenantID, cutoff)
+		if err != nil {
+			return err
+		}
+		report.Sessions, report.Messages = sessions, messages
+		return nil
+	}
+
+	for ctx.Err() == nil {
+		ids, err := s.repo.ListIdleSessionIDs(ctx, tenantID, cutoff, retentionPurgeBatch)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		var historyKnowledgeIDs []string
+		for _, id := range ids {
+			knowledgeIDs, err := s.messageRepo.GetKnowledgeIDsBySessionID(ctx, id)
+			if err != nil {
+				logger.Warnf(ctx, "Retention: failed to get chat history knowledge of session %s: %v", id, err)
+				continue
+			}
+			historyKnowledgeIDs = append(historyKnowledgeIDs, knowledgeIDs...)
+		}
+		messages, err := s.repo.PurgeSessions(ctx, tenantID, ids)
+		if err != nil {
+			return err
+		}
+		report.Sessions += int64(len(ids))
+		report.Messages += messages
+		if len(historyKnowledgeIDs) > 0 {
+			if err := s.knowledgeService.DeleteKnowledgeList(ctx, historyKnowledgeIDs); err != nil {
+				logger.Warnf(ctx, "Retention: failed to delete chat history knowledge of purged sessions: %v", err)
+			}
+		}
+	}
+	for ctx.Err() == nil {
+		deleted, err := s.repo.PurgeOldMessages(ctx, tenantID, cutoff, retentionPurgeBatch)
+		if err != nil {
+			return err
+		}
+		report.Messages += deleted
+		if deleted < retentionPurgeBatch {
+			break
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.repo.PurgeKnowledgeGaps(ctx, tenantID, cutoff)
+}
+
+// runMemory deletes the tenant's memory episodes created before the cutoff.
+// Tenants whose memory graph is unavailable have nothing to delete.
+func (s *retentionService) runMemory(
+	ctx context.Context,
+	tenantID uint64,
+	report *types.RetentionReport,
+	dryRun bool,
+) error {
+	if report.MemoryCutoff == nil || s.memoryRepo == nil || !s.memoryRepo.IsAvailable(ctx) {
+		return nil
+	}
+	users, err := s.memoryRepo.ListMemoryUsers(ctx)
+	if err != nil {
+		return err
+	}
+	for _, user := range users {
+		if user.TenantID != tenantID {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		episodes, err := s.memoryRepo.ListUserEpisodes(ctx, tenantID, user.UserID)
+		if err != nil {
+			return err
+		}
+		var expired []string
+		for _, episode := range episodes {
+			if episode.CreatedAt.Before(*report.MemoryCutoff) {
+				expired = append(expired, episode.ID)
+			}
+		}
+		if len(expired) == 0 {
+			continue
+		}
+		if !dryRun {
+			if err := s.memoryRepo.DeleteEpisodes(ctx, tenantID, expired); err != nil {
+				return err
+			}
+		}
+		report.Episodes += int64(len(expired))
+	}
+	return nil
+}
+
+// runKnowledge deletes the documents of the policy's knowledge base created
+// before the cutoff, through the regular document deletion so their chunks,
+// index rows and files go too.
+func (s *retentionService) runKnowledge(
+	ctx context.Context,
+	policy *types.RetentionPolicy,
+	report *types.RetentionReport,
+	dryRun bool,
+) error {
+	// A policy outliving its knowledge base reports ErrKnowledgeBaseNotFound
+	// so the scheduler can remove it.
+	if !dryRun {
+		if _, err := s.kbRepo.GetKnowledgeBaseByIDAndTenant(ctx, policy.KnowledgeBaseID, policy.TenantID); err != nil {
+			return err
+		}
+	}
+	if report.KnowledgeCutoff == nil {
+		return nil
+	}
+	cutoff := *report.KnowledgeCutoff
+	if dryRun {
+		count, err := s.repo.CountOldKnowledge(ctx, policy.TenantID, policy.KnowledgeBaseID, cutoff)
+		if err != nil {
+			return err
+		}
+		report.Knowledge = count
+		return nil
+	}
+	for ctx.Err() == nil {
+		ids, err := s.repo.ListOldKnowledgeIDs(ctx, policy.TenantID, policy.KnowledgeBaseID, cutoff, retentionPurgeBatch)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		if err := s.knowledgeService.DeleteKnowledgeList(ctx, ids); err != nil {
+			return err
+		}
+		report.Knowledge += int64(len(ids))
+	}
+	return ctx.Err()
+}
+
+// getPolicy loads a policy, or a disabled default when none is configured.
+func (s *retentionService) getPolicy(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+) (*types.RetentionPolicy, error) {
+	policy, err := s.repo.GetPolicy(ctx, tenantID, kbID)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		policy = &types.RetentionPolicy{TenantID: tenantID, KnowledgeBaseID: kbID}
+	}
+	return policy, nil
+}
+
+// savePolicy replaces current with the request. Enabling a policy makes its
+// first run due one RetentionRunInterval from now, leaving time to preview.
+func (s *retentionService) savePolicy(
+	ctx context.Context,
+	current *types.RetentionPolicy,
+	req *types.RetentionPolicyRequest,
+) (*types.RetentionPolicy, error) {
+	now := time.Now()
+	policy := &types.RetentionPolicy{
+		ID:                     current.ID,
+		TenantID:               current.TenantID,
+		KnowledgeBaseID:        current.KnowledgeBaseID,
+		Enabled:                req.Enabled,
+		SessionRetentionDays:   req.SessionRetentionDays,
+		MemoryRetentionDays:    req.MemoryRetentionDays,
+		KnowledgeRetentionDays: req.KnowledgeRetentionDays,
+		KnowledgeExpiresAt:     req.KnowledgeExpiresAt,
+		LastRunAt:              current.LastRunAt,
+		CreatedAt:              current.CreatedAt,
+		UpdatedAt:              now,
+	}
+	if policy.ID == "" {
+		policy.ID = uuid.New().String()
+	}
+	if policy.CreatedAt.IsZero() {
+		policy.CreatedAt = now
+	}
+	if policy.Enabled {
+		next := current.NextRunAt
+		if !current.Enabled || next == nil {
+			at := now.Add(types.RetentionRunInterval)
+			next = &at
+		}
+		policy.NextRunAt = next
+	}
+	if err := s.repo.SavePolicy(ctx, policy); err != nil {
+		logger.Errorf(ctx, "Failed to save retention policy of tenant %d: %v", policy.TenantID, err)
+		return nil, err
+	}
+	s.emitAudit(ctx, policy, types.AuditActionRetentionPolicyUpdated, auditActor(ctx), req)
+	return policy, nil
+}
+
+// ownKnowledgeBase loads a knowledge base of the caller's tenant.
+func (s *retentionService) ownKnowledgeBase(ctx context.Context, kbID string) (*types.KnowledgeBase, error) {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil || kb.TenantID != tenantID {
+		return nil, werrors.NewNotFoundError("知识库不存在")
+	}
+	return kb, nil
+}
+
+// emitAudit records a retention event. The actor is empty for runs of the
+// scheduler.
+func (s *retentionService) emitAudit(
+	ctx context.Context,
+	policy *types.RetentionPolicy,
+	action types.AuditAction,
+	actor string,
+	details interface{},
+) {
+	if s.audit == nil {
+		return
+	}
+	raw, _ := json.Marshal(details)
+	entry := &types.AuditLog{
+		TenantID:    policy.TenantID,
+		ActorUserID: actor,
+		Action:      action,
+		TargetType:  "retention_policy",
+		TargetID:    policy.ID,
+		Outcome:     types.AuditOutcomeSuccess,
+		Details:     types.JSON(raw),
+	}
+	if actor != "" {
+		entry.ActorRole = auditActorRole(ctx)
+	}
+	_ = s.audit.Log(ctx, entry)
+}
+
+// validateRetentionDays checks retention periods: 0 keeps data forever.
+func validateRetentionDays(days ...int) error {
+	for _, d := range days {
+		if d < 0 || d > types.MaxRetentionDays {
+			return werrors.NewBadRequestError("保留天数必须在 0 到 3650 之间，0 表示永久保留")
+		}
+	}
+	return nil
+}
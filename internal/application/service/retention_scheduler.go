@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// RetentionScheduler applies the retention policies that are due. Each due
+// policy is claimed with a conditional update of its next run, so replicas
+// ticking at the same time purge once. Policies of deleted knowledge bases
+// are removed.
+type RetentionScheduler struct {
+	retentionRepo    interfaces.RetentionRepository
+	tenantRepo       interfaces.TenantRepository
+	retentionService interfaces.RetentionService
+	interval         time.Duration
+	now              func() time.Time
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	// started lets Stop tell "never started" apart from "running"; see
+	// AuditLogRetentionRunner.
+	started atomic.Bool
+}
+
+const (
+	// retentionScheduleInterval is the gap between checks for due
+	// policies. Policies run daily, so an hour late is close enough.
+	retentionScheduleInterval = time.Hour
+	// retentionScheduleStartupDelay holds the first check until after boot.
+	retentionScheduleStartupDelay = 5 * time.Minute
+	// retentionScheduleTimeout bounds a single check; a policy cut short
+	// carries on from where it stopped on its next run.
+	retentionScheduleTimeout = 30 * time.Minute
+	// retentionScheduleBatch caps the policies applied per check; the rest
+	// are picked up by the next one.
+	retentionScheduleBatch = 50
+)
+
+// NewRetentionScheduler constructs the scheduler. Nothing fires until
+// Start is called.
+func NewRetentionScheduler(
+	retentionRepo interfaces.RetentionRepository,
+	tenantRepo interfaces.TenantRepository,
+	retentionService interfaces.RetentionService,
+) *RetentionScheduler {
+	return &RetentionScheduler{
+		retentionRepo:    retentionRepo,
+		tenantRepo:       tenantRepo,
+		retentionService: retentionService,
+		interval:         retentionScheduleInterval,
+		now:              time.Now,
+		stopCh:           make(chan struct{}),
+		doneCh:           make(chan struct{}),
+	}
+}
+
+// Start spins up the background goroutine. Calling it more than once is a
+// no-op.
+func (r *RetentionScheduler) Start(ctx context.Context) {
+	if r == nil || r.retentionRepo == nil {
+		return
+	}
+	r.startOnce.Do(func() {
+		r.started.Store(true)
+		logger.Infof(ctx, "[retention] starting scheduler: interval=%s", r.interval)
+		go r.loop()
+	})
+}
+
+// Stop signals the loop to exit and blocks until it returns. Idempotent.
+func (r *RetentionScheduler) Stop() {
+	if r == nil || !r.started.Load() {
+		return
+	}
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+	})
+	<-r.doneCh
+}
+
+func (r *RetentionScheduler) loop() {
+	defer close(r.doneCh)
+
+	startupTimer := time.NewTimer(retentionScheduleStartupDelay)
+	defer startupTimer.Stop()
+	select {
+	case <-startupTimer.C:
+	case <-r.stopCh:
+		return
+	}
+
+	r.runOnce()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.runOnce()
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+// runOnce applies every due policy it wins. Failures are logged; the
+// policy has already moved to its next run, which picks up what is left.
+func (r *RetentionScheduler) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), retentionScheduleTimeout)
+	defer cancel()
+
+	now := r.now()
+	policies, err := r.retentionRepo.ListDuePolicies(ctx, now, retentionScheduleBatch)
+	if err != nil {
+		logger.Warnf(ctx, "[retention] failed to list due policies: %v", err)
+		return
+	}
+	for _, policy := range policies {
+		if ctx.Err() != nil {
+			return
+		}
+		r.runPolicy(ctx, policy, now)
+	}
+}
+
+func (r *RetentionScheduler) runPolicy(ctx context.Context, policy *types.RetentionPolicy, now time.Time) {
+	won, err := r.retentionRepo.ClaimPolicy(ctx, policy.ID, *policy.NextRunAt, now.Add(types.RetentionRunInterval))
+	if err != nil {
+		logger.Warnf(ctx, "[retention] failed to claim policy %s: %v", policy.ID, err)
+		return
+	}
+	if !won {
+		return
+	}
+
+	tenant, err := r.tenantRepo.GetTenantByID(ctx, policy.TenantID)
+	if err != nil {
+		logger.Warnf(ctx, "[retention] failed to get tenant %d: %v", policy.TenantID, err)
+		return
+	}
+	tenantCtx := context.WithValue(ctx, types.TenantIDContextKey, policy.TenantID)
+	tenantCtx = context.WithValue(tenantCtx, types.TenantInfoContextKey, tenant)
+	if _, err := r.retentionService.ApplyPolicy(tenantCtx, policy); err != nil {
+		if errors.Is(err, repository.ErrKnowledgeBaseNotFound) {
+			logger.Infof(ctx, "[retention] knowledge base %s is gone, removing its policy", policy.KnowledgeBaseID)
+			if err := r.retentionRepo.DeletePolicy(ctx, policy.ID); err != nil {
+				logger.Warnf(ctx, "[retention] failed to remove policy %s: %v", policy.ID, err)
+			}
+			return
+		}
+		logger.Warnf(ctx, "[retention] failed to apply policy %s: %v", policy.ID, err)
+	}
+}
@@ -0,0 +1,630 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hibiken/asynq"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/Tencent/WeKnora/internal/application/service/metric"
+	"github.com/Tencent/WeKnora/internal/config"
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// evalRunConcurrency is how many questions of a run are evaluated at once
+	evalRunConcurrency = 4
+	// evalMaxCompareRuns bounds the runs of one comparison
+	evalMaxCompareRuns = 10
+	// evalJudgeMaxContextRunes bounds the retrieved context shown to the judge
+	evalJudgeMaxContextRunes = 8000
+)
+
+const evalJudgePrompt = `You are grading an answer produced by a retrieval-augmented assistant.
+
+Score two criteria between 0 and 1:
+- faithfulness: the share of the answer's claims that the retrieved context supports. An answer that only says it cannot answer scores 1.
+- answer_relevance: how directly and completely the answer addresses the question, whether or not it is correct.
+
+Respond with a JSON object only, no commentary:
+{"faithfulness": <0-1>, "answer_relevance": <0-1>, "reason": "<one sentence>"}
+
+Question: {{question}}
+{{reference}}
+Retrieved context:
+{{context}}
+
+Answer:
+{{answer}}`
+
+// retrievalEvalService runs golden datasets against knowledge bases. A run
+// goes through the same chat pipeline stages as a conversation, with the
+// retrieval settings under test, so comparing two runs of a dataset shows
+// what a configuration change does to retrieval and answer quality.
+type retrievalEvalService struct {
+	config         *config.Config
+	repo           interfaces.RetrievalEvalRepository
+	kbRepo         interfaces.KnowledgeBaseRepository
+	tenantRepo     interfaces.TenantRepository
+	modelService   interfaces.ModelService
+	sessionService interfaces.SessionService
+	task           interfaces.TaskEnqueuer
+}
+
+// NewRetrievalEvalService creates the retrieval evaluation service.
+func NewRetrievalEvalService(
+	config *config.Config,
+	repo interfaces.RetrievalEvalRepository,
+	kbRepo interfaces.KnowledgeBaseRepository,
+	tenantRepo interfaces.TenantRepository,
+	modelService interfaces.ModelService,
+	sessionService interfaces.SessionService,
+	task interfaces.TaskEnqueuer,
+) interfaces.RetrievalEvalService {
+	return &retrievalEvalService{
+		config:         config,
+		repo:           repo,
+		kbRepo:         kbRepo,
+		tenantRepo:     tenantRepo,
+		modelService:   modelService,
+		sessionService: sessionService,
+		task:           task,
+	}
+}
+
+// CreateDataset stores a golden dataset of the caller's tenant
+func (s *retrievalEvalService) CreateDataset(
+	ctx context.Context, req *types.EvalDatasetRequest,
+) (*types.EvalDataset, error) {
+	if err := req.Validate(); err != nil {
+		return nil, werrors.NewBadRequestError("评测数据集不合法").WithDetails(err.Error())
+	}
+	tenantID := types.MustTenantIDFromContext(ctx)
+	userID, _ := types.UserIDFromContext(ctx)
+	dataset := &types.EvalDataset{
+		ID:          uuid.New().String(),
+		TenantID:    tenantID,
+		Name:        req.Name,
+		Description: req.Description,
+		ItemCount:   len(req.Items),
+		CreatedBy:   userID,
+	}
+	items := make([]*types.EvalDatasetItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = &types.EvalDatasetItem{
+			ID:              uuid.New().String(),
+			DatasetID:       dataset.ID,
+			Position:        i,
+			Question:        item.Question,
+			ExpectedAnswer:  strings.TrimSpace(item.ExpectedAnswer),
+			ExpectedSources: item.ExpectedSources,
+		}
+	}
+	if err := s.repo.CreateDataset(ctx, dataset, items); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Created evaluation dataset %s with %d items", dataset.ID, len(items))
+	dataset.Items = items
+	return dataset, nil
+}
+
+// ListDatasets returns the golden datasets of the caller's tenant
+func (s *retrievalEvalService) ListDatasets(ctx context.Context) ([]*types.EvalDataset, error) {
+	return s.repo.ListDatasets(ctx, types.MustTenantIDFromContext(ctx))
+}
+
+// GetDataset returns a golden dataset with its items
+func (s *retrievalEvalService) GetDataset(ctx context.Context, id string) (*types.EvalDataset, error) {
+	dataset, err := s.getDataset(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	dataset.Items, err = s.repo.ListDatasetItems(ctx, dataset.ID)
+	if err != nil {
+		return nil, err
+	}
+	return dataset, nil
+}
+
+func (s *retrievalEvalService) getDataset(ctx context.Context, id string) (*types.EvalDataset, error) {
+	dataset, err := s.repo.GetDataset(ctx, types.MustTenantIDFromContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	if dataset == nil {
+		return nil, werrors.NewNotFoundError("评测数据集不存在")
+	}
+	return dataset, nil
+}
+
+// DeleteDataset deletes a golden dataset with its runs
+func (s *retrievalEvalService) DeleteDataset(ctx context.Context, id string) error {
+	if _, err := s.getDataset(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteDataset(ctx, types.MustTenantIDFromContext(ctx), id)
+}
+
+// CreateRun validates a run and enqueues it
+func (s *retrievalEvalService) CreateRun(ctx context.Context, req *types.EvalRunRequest) (*types.EvalRun, error) {
+	if err := req.Config.Validate(); err != nil {
+		return nil, werrors.NewBadRequestError("评测配置不合法").WithDetails(err.Error())
+	}
+	req.Config.ChunkingConfig = nil
+	tenantID := types.MustTenantIDFromContext(ctx)
+	dataset, err := s.getDataset(ctx, req.DatasetID)
+	if err != nil {
+		return nil, err
+	}
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, req.KnowledgeBaseID)
+	if err != nil || kb == nil || kb.TenantID != tenantID {
+		return nil, werrors.NewNotFoundError("知识库不存在")
+	}
+	if req.Config.Mode == types.EvalRunModeRAG && req.Config.ChatModelID == "" && kb.SummaryModelID == "" {
+		return nil, werrors.NewBadRequestError("知识库未配置摘要模型，请指定对话模型")
+	}
+	for _, modelID := range []string{req.Config.RerankModelID, req.Config.ChatModelID, req.Config.JudgeModelID} {
+		if modelID == "" {
+			continue
+		}
+		if model, err := s.modelService.GetModelByID(ctx, modelID); err != nil || model == nil {
+			return nil, werrors.NewBadRequestError("模型不存在").WithDetails(modelID)
+		}
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = fmt.Sprintf("%s @ %s", dataset.Name, kb.Name)
+	}
+	userID, _ := types.UserIDFromContext(ctx)
+	run := &types.EvalRun{
+		ID:              uuid.New().String(),
+		TenantID:        tenantID,
+		DatasetID:       dataset.ID,
+		KnowledgeBaseID: kb.ID,
+		Name:            name,
+		Status:          types.EvalRunStatusPending,
+		Config:          req.Config,
+		Total:           dataset.ItemCount,
+		CreatedBy:       userID,
+	}
+	if err := s.repo.CreateRun(ctx, run); err != nil {
+		return nil, err
+	}
+
+	lang, _ := types.LanguageFromContext(ctx)
+	payload := types.EvalRunPayload{TenantID: tenantID, RunID: run.ID, UserID: userID, Language: lang}
+	langfuse.InjectTracing(ctx, &payload)
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.task.Enqueue(asynq.NewTask(types.TypeEvalRun, payloadBytes),
+		asynq.Queue(types.QueueLow), asynq.MaxRetry(1), asynq.Timeout(6*time.Hour))
+	if err != nil {
+		s.failRun(ctx, run, fmt.Errorf("enqueue evaluation run: %w", err))
+		return nil, err
+	}
+	logger.Infof(ctx, "Evaluation run task enqueued: %s, run ID: %s", info.ID, run.ID)
+	return run, nil
+}
+
+// ListRuns returns the runs of the caller's tenant, newest first
+func (s *retrievalEvalService) ListRuns(ctx context.Context, datasetID string) ([]*types.EvalRun, error) {
+	return s.repo.ListRuns(ctx, types.MustTenantIDFromContext(ctx), datasetID)
+}
+
+// GetRun returns a run
+func (s *retrievalEvalService) GetRun(ctx context.Context, id string) (*types.EvalRun, error) {
+	run, err := s.repo.GetRun(ctx, types.MustTenantIDFromContext(ctx), id)
+	if err != nil {
+		return nil, err
+	}
+	if run == nil {
+		return nil, werrors.NewNotFoundError("评测运行不存在")
+	}
+	return run, nil
+}
+
+// ListRunResults returns the per-question results of a run
+func (s *retrievalEvalService) ListRunResults(ctx context.Context, id string) ([]*types.EvalRunResult, error) {
+	run, err := s.GetRun(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListRunResults(ctx, run.ID)
+}
+
+// DeleteRun deletes a run with its results
+func (s *retrievalEvalService) DeleteRun(ctx context.Context, id string) error {
+	if _, err := s.GetRun(ctx, id); err != nil {
+		return err
+	}
+	return s.repo.DeleteRun(ctx, types.MustTenantIDFromContext(ctx), id)
+}
+
+// CompareRuns compares completed runs against the first of them. When all
+// runs evaluated the same dataset, the comparison also lists the metrics of
+// every question side by side, so regressions can be traced to questions.
+func (s *retrievalEvalService) CompareRuns(ctx context.Context, ids []string) (*types.EvalRunComparison, error) {
+	seen := make(map[string]bool, len(ids))
+	unique := make([]string, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	if len(unique) < 2 || len(unique) > evalMaxCompareRuns {
+		return nil, werrors.NewBadRequestError(fmt.Sprintf("请选择 2 到 %d 个评测运行进行对比", evalMaxCompareRuns))
+	}
+
+	comparison := &types.EvalRunComparison{BaselineID: unique[0]}
+	sameDataset := true
+	for _, id := range unique {
+		run, err := s.GetRun(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if run.Status != types.EvalRunStatusCompleted {
+			return nil, werrors.NewBadRequestError("只能对比已完成的评测运行").WithDetails(id)
+		}
+		comparison.Runs = append(comparison.Runs, run)
+		sameDataset = sameDataset && run.DatasetID == comparison.Runs[0].DatasetID
+	}
+	baseline := comparison.Runs[0]
+	for _, run := range comparison.Runs[1:] {
+		comparison.Deltas = append(comparison.Deltas, types.DiffEvalMetrics(run.ID, run.Metrics, baseline.Metrics))
+	}
+	if !sameDataset {
+		return comparison, nil
+	}
+
+	items, err := s.repo.ListDatasetItems(ctx, baseline.DatasetID)
+	if err != nil {
+		return nil, err
+	}
+	byItem := make(map[string]*types.EvalItemComparison, len(items))
+	for _, item := range items {
+		c := &types.EvalItemComparison{
+			ItemID:   item.ID,
+			Question: item.Question,
+			Metrics:  make([]*types.EvalMetrics, len(comparison.Runs)),
+		}
+		byItem[item.ID] = c
+		comparison.Items = append(comparison.Items, c)
+	}
+	for i, run := range comparison.Runs {
+		results, err := s.repo.ListRunResults(ctx, run.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range results {
+			if c, ok := byItem[result.ItemID]; ok && result.ErrMsg == "" {
+				m := result.Metrics
+				c.Metrics[i] = &m
+			}
+		}
+	}
+	return comparison, nil
+}
+
+// ProcessEvalRun evaluates every question of a run's dataset and stores the
+// per-question results and the run's averaged metrics.
+func (s *retrievalEvalService) ProcessEvalRun(ctx context.Context, t *asynq.Task) error {
+	var payload types.EvalRunPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, payload.TenantID)
+	if payload.UserID != "" {
+		ctx = context.WithValue(ctx, types.UserIDContextKey, payload.UserID)
+	}
+	if payload.Language != "" {
+		ctx = context.WithValue(ctx, types.LanguageContextKey, payload.Language)
+	}
+
+	run, err := s.repo.GetRun(ctx, payload.TenantID, payload.RunID)
+	if err != nil {
+		return err
+	}
+	if run == nil {
+		logger.Warnf(ctx, "Evaluation run %s not found, skip", payload.RunID)
+		return nil
+	}
+	if run.Status == types.EvalRunStatusCompleted || run.Status == types.EvalRunStatusFailed {
+		return nil
+	}
+	tenant, err := s.tenantRepo.GetTenantByID(ctx, payload.TenantID)
+	if err != nil {
+		return err
+	}
+	ctx = context.WithValue(ctx, types.TenantInfoContextKey, tenant)
+
+	if err := s.executeRun(ctx, run); err != nil {
+		// A cancelled worker leaves the run to the retry; anything else is
+		// a problem with the run itself and retrying would not help.
+		if ctx.Err() != nil {
+			return err
+		}
+		s.failRun(ctx, run, err)
+	}
+	return nil
+}
+
+// executeRun evaluates the dataset of a run and completes it.
+func (s *retrievalEvalService) executeRun(ctx context.Context, run *types.EvalRun) error {
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, run.KnowledgeBaseID)
+	if err != nil || kb == nil || kb.TenantID != run.TenantID {
+		return fmt.Errorf("knowledge base %s not found", run.KnowledgeBaseID)
+	}
+	items, err := s.repo.ListDatasetItems(ctx, run.DatasetID)
+	if err != nil {
+		return fmt.Errorf("list dataset items: %w", err)
+	}
+	if len(items) == 0 {
+		return errors.New("dataset has no items")
+	}
+
+	base := s.evalChatManage(kb, &run.Config)
+	var judge chat.Chat
+	if run.Config.Mode == types.EvalRunModeRAG {
+		judgeModelID := run.Config.JudgeModelID
+		if judgeModelID == "" {
+			judgeModelID = base.ChatModelID
+		}
+		if judge, err = s.modelService.GetChatModel(ctx, judgeModelID); err != nil {
+			return fmt.Errorf("get judge model: %w", err)
+		}
+	}
+
+	chunking := kb.ChunkingConfig
+	run.Config.ChunkingConfig = &chunking
+	now := time.Now()
+	run.Status = types.EvalRunStatusRunning
+	run.StartedAt = &now
+	run.FinishedAt = nil
+	run.Total = len(items)
+	run.Finished, run.Failed = 0, 0
+	run.Metrics = nil
+	run.ErrMsg = ""
+	if err := s.repo.UpdateRun(ctx, run); err != nil {
+		return err
+	}
+	// A retried run starts over
+	if err := s.repo.DeleteRunResults(ctx, run.ID); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Evaluating run %s: %d questions against knowledge base %s in %s mode",
+		run.ID, len(items), kb.ID, run.Config.Mode)
+
+	results := make([]*types.EvalRunResult, len(items))
+	var mu sync.Mutex
+	var g errgroup.Group
+	g.SetLimit(evalRunConcurrency)
+	for i, item := range items {
+		g.Go(func() error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			result := s.evaluateItem(ctx, run, base, judge, item)
+			if err := s.repo.SaveRunResult(ctx, result); err != nil {
+				return err
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			results[i] = result
+			run.Finished++
+			if result.ErrMsg != "" {
+				run.Failed++
+			}
+			if err := s.repo.UpdateRun(ctx, run); err != nil {
+				logger.Warnf(ctx, "Failed to update progress of evaluation run %s: %v", run.ID, err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return err
+	}
+
+	finished := time.Now()
+	run.Metrics = types.AverageEvalMetrics(results)
+	run.Status = types.EvalRunStatusCompleted
+	run.FinishedAt = &finished
+	if err := s.repo.UpdateRun(ctx, run); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Evaluation run %s completed: recall@%d %.3f, MRR %.3f, %d of %d questions failed",
+		run.ID, run.Config.TopK, run.Metrics.RecallAtK, run.Metrics.MRR, run.Failed, run.Total)
+	return nil
+}
+
+// failRun marks a run failed with err.
+func (s *retrievalEvalService) failRun(ctx context.Context, run *types.EvalRun, err error) {
+	logger.Errorf(ctx, "Evaluation run %s failed: %v", run.ID, err)
+	now := time.Now()
+	run.Status = types.EvalRunStatusFailed
+	run.ErrMsg = err.Error()
+	run.FinishedAt = &now
+	if err := s.repo.UpdateRun(ctx, run); err != nil {
+		logger.Errorf(ctx, "Failed to mark evaluation run %s failed: %v", run.ID, err)
+	}
+}
+
+// evalChatManage builds the pipeline parameters of a run: the conversation
+// defaults, overridden by the run's configuration, searching kb only.
+func (s *retrievalEvalService) evalChatManage(kb *types.KnowledgeBase, cfg *types.EvalRunConfig) *types.ChatManage {
+	chatModelID := cfg.ChatModelID
+	if chatModelID == "" {
+		chatModelID = kb.SummaryModelID
+	}
+	req := conversationPipelineRequest(s.config, chatModelID, cfg.RerankModelID)
+	if cfg.EmbeddingTopK > 0 {
+		req.EmbeddingTopK = cfg.EmbeddingTopK
+	}
+	if cfg.VectorThreshold != nil {
+		req.VectorThreshold = *cfg.VectorThreshold
+	}
+	if cfg.KeywordThreshold != nil {
+		req.KeywordThreshold = *cfg.KeywordThreshold
+	}
+	if cfg.RerankTopK > 0 {
+		req.RerankTopK = cfg.RerankTopK
+	}
+	if cfg.RerankThreshold != nil {
+		req.RerankThreshold = *cfg.RerankThreshold
+	}
+	req.KnowledgeBaseIDs = []string{kb.ID}
+	req.SearchTargets = types.SearchTargets{
+		&types.SearchTarget{
+			Type:            types.SearchTargetTypeKnowledgeBase,
+			KnowledgeBaseID: kb.ID,
+		},
+	}
+	return &types.ChatManage{PipelineRequest: req}
+}
+
+// evaluateItem runs one question through the pipeline and scores it. A
+// pipeline error is recorded on the result rather than failing the run.
+func (s *retrievalEvalService) evaluateItem(
+	ctx context.Context, run *types.EvalRun, base *types.ChatManage, judge chat.Chat, item *types.EvalDatasetItem,
+) *types.EvalRunResult {
+	result := &types.EvalRunResult{
+		ID:              uuid.New().String(),
+		RunID:           run.ID,
+		ItemID:          item.ID,
+		Position:        item.Position,
+		Question:        item.Question,
+		ExpectedSources: len(item.ExpectedSources),
+	}
+	chatManage := base.Clone()
+	chatManage.Query = item.Question
+	chatManage.RewriteQuery = item.Question
+
+	events := []types.EventType{types.CHUNK_SEARCH, types.CHUNK_RERANK}
+	if run.Config.Mode == types.EvalRunModeRAG {
+		events = types.Pipeline["rag"]
+	}
+	start := time.Now()
+	err := s.sessionService.KnowledgeQAByEvent(ctx, chatManage, events)
+	result.Metrics.AvgLatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		logger.Warnf(ctx, "Evaluation run %s: question %d failed: %v", run.ID, item.Position, err)
+		result.ErrMsg = err.Error()
+		return result
+	}
+
+	// With a rerank model the reranked list is what the answer is built
+	// from, even when the threshold filtered it down to nothing.
+	ranking := chatManage.RerankResult
+	if base.RerankModelID == "" {
+		ranking = make([]*types.SearchResult, len(chatManage.SearchResult))
+		copy(ranking, chatManage.SearchResult)
+		sort.SliceStable(ranking, func(i, j int) bool { return ranking[i].Score > ranking[j].Score })
+	}
+	if len(ranking) > run.Config.TopK {
+		ranking = ranking[:run.Config.TopK]
+	}
+	scoreRanking(result, item.ExpectedSources, ranking, run.Config.TopK)
+
+	if run.Config.Mode == types.EvalRunModeRAG {
+		if chatManage.ChatResponse != nil {
+			result.Answer = chatManage.ChatResponse.Content
+		}
+		faithfulness, relevance, reason, err := judgeEvalAnswer(ctx, judge, item, chatManage.MergeResult, result.Answer)
+		if err != nil {
+			logger.Warnf(ctx, "Evaluation run %s: failed to judge question %d: %v", run.ID, item.Position, err)
+			result.JudgeReason = "judge failed: " + err.Error()
+		} else {
+			result.Metrics.Faithfulness = &faithfulness
+			result.Metrics.AnswerRelevance = &relevance
+			result.JudgeReason = reason
+		}
+	}
+	return result
+}
+
+// scoreRanking records the top k results of a question and their
+// retrieval metrics.
+func scoreRanking(result *types.EvalRunResult, expected types.EvalExpectedSources, ranking []*types.SearchResult, k int) {
+	matches := expected.MatchRanking(ranking)
+	result.Retrieved = make(types.EvalRetrievedSources, len(ranking))
+	for i, r := range ranking {
+		title := r.KnowledgeTitle
+		if title == "" {
+			title = r.KnowledgeFilename
+		}
+		result.Retrieved[i] = types.EvalRetrievedSource{
+			ChunkID:     r.ID,
+			KnowledgeID: r.KnowledgeID,
+			Title:       title,
+			Score:       r.Score,
+			Expected:    matches[i],
+		}
+	}
+	if len(expected) == 0 {
+		return
+	}
+	input := metric.RankingInput(matches, len(expected))
+	result.Metrics.RecallAtK = metric.NewRecallMetric().Compute(input)
+	result.Metrics.MRR = metric.NewMRRMetric().Compute(input)
+	result.Metrics.NDCGAtK = metric.NewNDCGMetric(k).Compute(input)
+	if result.Metrics.RecallAtK > 0 {
+		result.Metrics.HitRateAtK = 1
+	}
+}
+
+// judgeEvalAnswer has the judge model grade an answer's faithfulness to the
+// retrieved context and its relevance to the question.
+func judgeEvalAnswer(
+	ctx context.Context, judge chat.Chat, item *types.EvalDatasetItem, contextChunks []*types.SearchResult, answer string,
+) (faithfulness, relevance float64, reason string, err error) {
+	reference := ""
+	if item.ExpectedAnswer != "" {
+		reference = "Reference answer (use it to judge relevance, not faithfulness): " + item.ExpectedAnswer + "\n"
+	}
+	prompt := types.RenderPromptPlaceholders(evalJudgePrompt, types.PlaceholderValues{
+		"reference": reference,
+		"question":  item.Question,
+		"context":   judgeContext(contextChunks, evalJudgeMaxContextRunes),
+		"answer":    answer,
+	})
+
+	thinking := false
+	resp, err := judge.Chat(ctx, []chat.Message{{Role: "user", Content: prompt}}, &chat.ChatOptions{
+		Temperature: 0,
+		MaxTokens:   512,
+		Thinking:    &thinking,
+	})
+	if err != nil {
+		return 0, 0, "", err
+	}
+	var verdict struct {
+		Faithfulness    float64 `json:"faithfulness"`
+		AnswerRelevance float64 `json:"answer_relevance"`
+		Reason          string  `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(cleanLLMJSON(resp.Content)), &verdict); err != nil {
+		return 0, 0, "", fmt.Errorf("parse verdict: %w", err)
+	}
+	return clampScore(verdict.Faithfulness), clampScore(verdict.AnswerRelevance), verdict.Reason, nil
+}
+
+func clampScore(v float64) float64 {
+	return min(max(v, 0), 1)
+}
@@ -2,6 +2,7 @@ package retriever
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"maps"
 	"slices"
@@ -229,6 +230,75 @@ func (c *CompositeRetrieveEngine) BatchIndex(ctx context.Context,
 	return err
 }
 
+// BatchIndexEmbedded saves rows with pre-computed vectors to all registered
+// repositories. Every engine must implement interfaces.EmbeddedIndexer.
+func (c *CompositeRetrieveEngine) BatchIndexEmbedded(ctx context.Context,
+	modelID string, indexInfoList []*types.IndexInfo, embeddings map[string][]float32,
+) error {
+	indexInfoList = common.Deduplicate(func(info *types.IndexInfo) string { return info.SourceID }, indexInfoList...)
+	return c.concurrentExecWithError(ctx, func(ctx context.Context, engineInfo *engineInfo) error {
+		indexer, ok := engineInfo.retrieveEngine.(interfaces.EmbeddedIndexer)
+		if !ok {
+			return fmt.Errorf("retrieval engine %s does not support pre-computed embeddings",
+				engineInfo.retrieveEngine.EngineType())
+		}
+		if err := indexer.BatchIndexEmbedded(ctx, modelID, indexInfoList, embeddings, engineInfo.retrieverType); err != nil {
+			logger.Errorf(ctx, "Repository %s failed to batch save embedded rows: %v", engineInfo.retrieveEngine.EngineType(), err)
+			return err
+		}
+		return nil
+	})
+}
+
+// ExportIndices exports the rows of a knowledge base from the engine that
+// serves vector retrieval, so the rows carry their vectors. It returns
+// ErrExportUnsupported when no such engine can export.
+func (c *CompositeRetrieveEngine) ExportIndices(ctx context.Context,
+	knowledgeBaseID string, batchSize int, fn func([]*types.ExportedIndex) error,
+) error {
+	for _, engineInfo := range c.engineInfos {
+		if engineInfo == nil || !slices.Contains(engineInfo.retrieverType, types.VectorRetrieverType) {
+			continue
+		}
+		exporter, ok := engineInfo.retrieveEngine.(interfaces.IndexExporter)
+		if !ok {
+			continue
+		}
+		err := exporter.ExportIndices(ctx, knowledgeBaseID, batchSize, fn)
+		if errors.Is(err, ErrExportUnsupported) {
+			continue
+		}
+		return err
+	}
+	return ErrExportUnsupported
+}
+
+// MoveIndices reassigns the rows of the given knowledge to another knowledge
+// base in every engine, one engine after another. ErrMoveUnsupported means
+// at least one engine cannot move in place and others may already have;
+// callers fall back to deleting and re-indexing the knowledge everywhere.
+func (c *CompositeRetrieveEngine) MoveIndices(ctx context.Context,
+	knowledgeIDs []string, targetKnowledgeBaseID string, chunkTagMap map[string]string,
+) error {
+	movers := make([]interfaces.IndexMover, 0, len(c.engineInfos))
+	for _, engineInfo := range c.engineInfos {
+		if engineInfo == nil {
+			continue
+		}
+		mover, ok := engineInfo.retrieveEngine.(interfaces.IndexMover)
+		if !ok {
+			return ErrMoveUnsupported
+		}
+		movers = append(movers, mover)
+	}
+	for _, mover := range movers {
+		if err := mover.MoveIndices(ctx, knowledgeIDs, targetKnowledgeBaseID, chunkTagMap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // DeleteByChunkIDList deletes vector embeddings by chunk ID list from all registered repositories
 func (c *CompositeRetrieveEngine) DeleteByChunkIDList(ctx context.Context,
 	chunkIDList []string, dimension int, knowledgeType string,
@@ -298,6 +368,34 @@ func (c *CompositeRetrieveEngine) DeleteByKnowledgeIDList(ctx context.Context,
 	})
 }
 
+// DeleteByKnowledgeBaseIDList deletes every vector embedding of the knowledge base ID list from all registered repositories
+func (c *CompositeRetrieveEngine) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	return c.concurrentExecWithError(ctx, func(ctx context.Context, engineInfo *engineInfo) error {
+		if err := engineInfo.retrieveEngine.DeleteByKnowledgeBaseIDList(ctx, knowledgeBaseIDList, dimension, knowledgeType); err != nil {
+			logger.GetLogger(ctx).Errorf("Repository %s failed to delete knowledge base ID list: %v",
+				engineInfo.retrieveEngine.EngineType(), err)
+			return err
+		}
+		return nil
+	})
+}
+
+// DeleteByTagIDList deletes vector embeddings by tag ID list from all registered repositories
+func (c *CompositeRetrieveEngine) DeleteByTagIDList(ctx context.Context,
+	tagIDList []string, dimension int, knowledgeType string,
+) error {
+	return c.concurrentExecWithError(ctx, func(ctx context.Context, engineInfo *engineInfo) error {
+		if err := engineInfo.retrieveEngine.DeleteByTagIDList(ctx, tagIDList, dimension, knowledgeType); err != nil {
+			logger.GetLogger(ctx).Errorf("Repository %s failed to delete tag ID list: %v",
+				engineInfo.retrieveEngine.EngineType(), err)
+			return err
+		}
+		return nil
+	})
+}
+
 // EstimateStorageSize estimates the storage size required for the provided index information
 func (c *CompositeRetrieveEngine) EstimateStorageSize(ctx context.Context,
 	embedder embedding.Embedder, indexInfoList []*types.IndexInfo,
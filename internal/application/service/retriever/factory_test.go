@@ -101,6 +101,14 @@ func (f *fakeEngine) DeleteByKnowledgeIDList(ctx context.Context, _ []string, _
 	panic("fakeEngine.DeleteByKnowledgeIDList: not used in factory tests")
 }
 
+func (f *fakeEngine) DeleteByKnowledgeBaseIDList(ctx context.Context, _ []string, _ int, _ string) error {
+	panic("fakeEngine.DeleteByKnowledgeBaseIDList: not used in factory tests")
+}
+
+func (f *fakeEngine) DeleteByTagIDList(ctx context.Context, _ []string, _ int, _ string) error {
+	panic("fakeEngine.DeleteByTagIDList: not used in factory tests")
+}
+
 func (f *fakeEngine) BatchUpdateChunkEnabledStatus(ctx context.Context, _ map[string]bool) error {
 	panic("fakeEngine.BatchUpdateChunkEnabledStatus: not used in factory tests")
 }
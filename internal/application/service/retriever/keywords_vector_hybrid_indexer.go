@@ -2,6 +2,9 @@ package retriever
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"regexp"
 	"slices"
 	"strings"
@@ -9,6 +12,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/models/utils"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -37,11 +41,41 @@ var embeddingImagePayloadPatterns = []*regexp.Regexp{
 	regexp.MustCompile(`(?i)data:[a-z0-9.+/-]+;base64,[a-z0-9+/=]{200,}`),
 }
 
+// ErrReindexUnsupported is returned by ReindexCollections when the wrapped
+// repository has no create-time schema settings to rebuild.
+var ErrReindexUnsupported = errors.New("engine does not support collection reindex")
+
+// ErrDedupUnsupported is returned by DeduplicateIndices when the wrapped
+// repository has no duplicate-row repair routine.
+var ErrDedupUnsupported = errors.New("engine does not support index deduplication")
+
+// ErrFilterValidationUnsupported is returned by ValidateFilter when the
+// wrapped repository does not accept structured filter conditions.
+var ErrFilterValidationUnsupported = errors.New("engine does not support filter validation")
+
+// ErrModelSpaceMigrationUnsupported is returned by MigrateModelSpaces when the
+// wrapped repository does not keep per-model vector spaces.
+var ErrModelSpaceMigrationUnsupported = errors.New("engine does not support model space migration")
+
+// ErrLoadStateUnsupported is returned by LoadStates when the wrapped
+// repository serves queries without loading collections first.
+var ErrLoadStateUnsupported = errors.New("engine does not report collection load state")
+
+// ErrExportUnsupported is returned by ExportIndices when the wrapped
+// repository cannot read its rows back with their vectors.
+var ErrExportUnsupported = errors.New("engine does not support index export")
+
+// ErrMoveUnsupported is returned by MoveIndices when the wrapped repository
+// cannot reassign rows to another knowledge base in place.
+var ErrMoveUnsupported = errors.New("engine does not support moving indices")
+
 // KeywordsVectorHybridRetrieveEngineService implements a hybrid retrieval engine
 // that supports both keyword-based and vector-based retrieval
 type KeywordsVectorHybridRetrieveEngineService struct {
 	indexRepository interfaces.RetrieveEngineRepository
 	engineType      types.RetrieverEngineType
+	// cache is the shared retrieval cache; nil when disabled.
+	cache *retrieveCache
 }
 
 // NewKVHybridRetrieveEngine creates a new instance of the hybrid retrieval engine
@@ -49,7 +83,11 @@ type KeywordsVectorHybridRetrieveEngineService struct {
 func NewKVHybridRetrieveEngine(indexRepository interfaces.RetrieveEngineRepository,
 	engineType types.RetrieverEngineType,
 ) interfaces.RetrieveEngineService {
-	return &KeywordsVectorHybridRetrieveEngineService{indexRepository: indexRepository, engineType: engineType}
+	return &KeywordsVectorHybridRetrieveEngineService{
+		indexRepository: indexRepository,
+		engineType:      engineType,
+		cache:           getRetrieveCache(),
+	}
 }
 
 // EngineType returns the type of the retrieval engine
@@ -61,7 +99,22 @@ func (v *KeywordsVectorHybridRetrieveEngineService) EngineType() types.Retriever
 func (v *KeywordsVectorHybridRetrieveEngineService) Retrieve(ctx context.Context,
 	params types.RetrieveParams,
 ) ([]*types.RetrieveResult, error) {
-	return v.indexRepository.Retrieve(ctx, params)
+	if v.cache == nil {
+		return v.retrieve(ctx, params)
+	}
+	return v.cache.retrieve(ctx, v.engineType, params, func() ([]*types.RetrieveResult, error) {
+		return v.retrieve(ctx, params)
+	})
+}
+
+// retrieve queries the repository and reports the latency to Prometheus.
+func (v *KeywordsVectorHybridRetrieveEngineService) retrieve(ctx context.Context,
+	params types.RetrieveParams,
+) ([]*types.RetrieveResult, error) {
+	start := time.Now()
+	results, err := v.indexRepository.Retrieve(ctx, params)
+	metrics.ObserveRetrieval(ctx, v.engineType, params.RetrieverType, start, err)
+	return results, err
 }
 
 // Index creates embeddings for the content and saves it to the repository
@@ -77,8 +130,10 @@ func (v *KeywordsVectorHybridRetrieveEngineService) Index(ctx context.Context,
 			return err
 		}
 		embeddingMap[indexInfo.SourceID] = embedding
+		stampEmbeddingModel(embedder, []*types.IndexInfo{indexInfo})
 	}
 	params["embedding"] = embeddingMap
+	defer v.cache.invalidateKBs(ctx, indexInfo.KnowledgeBaseID)
 	return v.indexRepository.Save(ctx, indexInfo, params)
 }
 
@@ -90,6 +145,7 @@ func (v *KeywordsVectorHybridRetrieveEngineService) BatchIndex(ctx context.Conte
 	if len(indexInfoList) == 0 {
 		return nil
 	}
+	defer v.invalidateIndexed(ctx, indexInfoList)
 
 	if slices.Contains(retrieverTypes, types.VectorRetrieverType) {
 		var contentList []string
@@ -100,6 +156,7 @@ func (v *KeywordsVectorHybridRetrieveEngineService) BatchIndex(ctx context.Conte
 		if err != nil {
 			return err
 		}
+		stampEmbeddingModel(embedder, indexInfoList)
 
 		batchSize := 40
 		chunks := utils.ChunkSlice(indexInfoList, batchSize)
@@ -125,6 +182,50 @@ func (v *KeywordsVectorHybridRetrieveEngineService) BatchIndex(ctx context.Conte
 	return v.boundedConcurrentBatchSaveNoEmbedding(ctx, chunks, maxConcurrency)
 }
 
+// BatchIndexEmbedded saves rows with caller-supplied vectors, skipping the
+// embedding call. Batching and write concurrency match BatchIndex.
+func (v *KeywordsVectorHybridRetrieveEngineService) BatchIndexEmbedded(ctx context.Context,
+	modelID string, indexInfoList []*types.IndexInfo, embeddings map[string][]float32,
+	retrieverTypes []types.RetrieverType,
+) error {
+	if len(indexInfoList) == 0 {
+		return nil
+	}
+	defer v.invalidateIndexed(ctx, indexInfoList)
+
+	const maxConcurrency = 5
+	if !slices.Contains(retrieverTypes, types.VectorRetrieverType) {
+		chunks := utils.ChunkSlice(indexInfoList, 10)
+		return v.boundedConcurrentBatchSaveNoEmbedding(ctx, chunks, maxConcurrency)
+	}
+
+	ordered := make([][]float32, len(indexInfoList))
+	for i, indexInfo := range indexInfoList {
+		vec, ok := embeddings[indexInfo.SourceID]
+		if !ok {
+			return fmt.Errorf("missing embedding for source %s", indexInfo.SourceID)
+		}
+		ordered[i] = vec
+		if indexInfo.EmbeddingModelID == "" {
+			indexInfo.EmbeddingModelID = modelID
+		}
+	}
+	batchSize := 40
+	return v.boundedConcurrentBatchSave(ctx, utils.ChunkSlice(indexInfoList, batchSize), ordered, batchSize, maxConcurrency)
+}
+
+// stampEmbeddingModel records the embedder's model on each IndexInfo so
+// repositories with per-model vector spaces can route the rows. Callers that
+// already set a model ID keep it.
+func stampEmbeddingModel(embedder embedding.Embedder, indexInfoList []*types.IndexInfo) {
+	modelID := embedder.GetModelID()
+	for _, indexInfo := range indexInfoList {
+		if indexInfo.EmbeddingModelID == "" {
+			indexInfo.EmbeddingModelID = modelID
+		}
+	}
+}
+
 // batchEmbedWithBackoff calls BatchEmbedWithPool with exponential backoff on
 // transient failures (200 / 400 / 800 / 1600 / 3200 ms). It returns the last
 // embedding result on success or the last error if every attempt failed.
@@ -268,10 +369,29 @@ func (v *KeywordsVectorHybridRetrieveEngineService) boundedConcurrentBatchSaveNo
 	return g.Wait()
 }
 
+// invalidateIndexed drops cached results of the knowledge bases written by
+// a batch index. Deferred so readers never cache pre-write results under the
+// post-write generation.
+func (v *KeywordsVectorHybridRetrieveEngineService) invalidateIndexed(
+	ctx context.Context, indexInfoList []*types.IndexInfo,
+) {
+	if v.cache == nil {
+		return
+	}
+	kbIDs := make([]string, 0, 1)
+	for _, info := range indexInfoList {
+		if !slices.Contains(kbIDs, info.KnowledgeBaseID) {
+			kbIDs = append(kbIDs, info.KnowledgeBaseID)
+		}
+	}
+	v.cache.invalidateKBs(ctx, kbIDs...)
+}
+
 // DeleteByChunkIDList deletes vectors by their chunk IDs
 func (v *KeywordsVectorHybridRetrieveEngineService) DeleteByChunkIDList(ctx context.Context,
 	indexIDList []string, dimension int, knowledgeType string,
 ) error {
+	defer v.cache.invalidateEngine(ctx, v.engineType)
 	return v.indexRepository.DeleteByChunkIDList(ctx, indexIDList, dimension, knowledgeType)
 }
 
@@ -279,6 +399,7 @@ func (v *KeywordsVectorHybridRetrieveEngineService) DeleteByChunkIDList(ctx cont
 func (v *KeywordsVectorHybridRetrieveEngineService) DeleteBySourceIDList(ctx context.Context,
 	sourceIDList []string, dimension int, knowledgeType string,
 ) error {
+	defer v.cache.invalidateEngine(ctx, v.engineType)
 	return v.indexRepository.DeleteBySourceIDList(ctx, sourceIDList, dimension, knowledgeType)
 }
 
@@ -286,9 +407,26 @@ func (v *KeywordsVectorHybridRetrieveEngineService) DeleteBySourceIDList(ctx con
 func (v *KeywordsVectorHybridRetrieveEngineService) DeleteByKnowledgeIDList(ctx context.Context,
 	knowledgeIDList []string, dimension int, knowledgeType string,
 ) error {
+	defer v.cache.invalidateEngine(ctx, v.engineType)
 	return v.indexRepository.DeleteByKnowledgeIDList(ctx, knowledgeIDList, dimension, knowledgeType)
 }
 
+// DeleteByKnowledgeBaseIDList deletes every vector of the given knowledge bases
+func (v *KeywordsVectorHybridRetrieveEngineService) DeleteByKnowledgeBaseIDList(ctx context.Context,
+	knowledgeBaseIDList []string, dimension int, knowledgeType string,
+) error {
+	defer v.cache.invalidateKBs(ctx, knowledgeBaseIDList...)
+	return v.indexRepository.DeleteByKnowledgeBaseIDList(ctx, knowledgeBaseIDList, dimension, knowledgeType)
+}
+
+// DeleteByTagIDList deletes vectors by their tag IDs
+func (v *KeywordsVectorHybridRetrieveEngineService) DeleteByTagIDList(ctx context.Context,
+	tagIDList []string, dimension int, knowledgeType string,
+) error {
+	defer v.cache.invalidateEngine(ctx, v.engineType)
+	return v.indexRepository.DeleteByTagIDList(ctx, tagIDList, dimension, knowledgeType)
+}
+
 // Support returns the retriever types supported by this engine
 func (v *KeywordsVectorHybridRetrieveEngineService) Support() []types.RetrieverType {
 	return v.indexRepository.Support()
@@ -326,6 +464,7 @@ func (v *KeywordsVectorHybridRetrieveEngineService) CopyIndices(
 	logger.Infof(ctx, "Copy indices from knowledge base %s to %s, mapping relation count: %d",
 		sourceKnowledgeBaseID, targetKnowledgeBaseID, len(sourceToTargetChunkIDMap),
 	)
+	defer v.cache.invalidateKBs(ctx, targetKnowledgeBaseID)
 	return v.indexRepository.CopyIndices(
 		ctx, sourceKnowledgeBaseID, sourceToTargetKBIDMap, sourceToTargetChunkIDMap, targetKnowledgeBaseID, dimension, knowledgeType,
 	)
@@ -336,6 +475,7 @@ func (v *KeywordsVectorHybridRetrieveEngineService) BatchUpdateChunkEnabledStatu
 	ctx context.Context,
 	chunkStatusMap map[string]bool,
 ) error {
+	defer v.cache.invalidateEngine(ctx, v.engineType)
 	return v.indexRepository.BatchUpdateChunkEnabledStatus(ctx, chunkStatusMap)
 }
 
@@ -344,5 +484,96 @@ func (v *KeywordsVectorHybridRetrieveEngineService) BatchUpdateChunkTagID(
 	ctx context.Context,
 	chunkTagMap map[string]string,
 ) error {
+	defer v.cache.invalidateEngine(ctx, v.engineType)
 	return v.indexRepository.BatchUpdateChunkTagID(ctx, chunkTagMap)
 }
+
+// ReindexCollections rebuilds the repository's collections when the
+// underlying repository supports it; see interfaces.CollectionReindexer.
+func (v *KeywordsVectorHybridRetrieveEngineService) ReindexCollections(
+	ctx context.Context,
+) (*types.ReindexReport, error) {
+	reindexer, ok := v.indexRepository.(interfaces.CollectionReindexer)
+	if !ok {
+		return nil, ErrReindexUnsupported
+	}
+	defer v.cache.invalidateEngine(ctx, v.engineType)
+	return reindexer.ReindexCollections(ctx)
+}
+
+// DeduplicateIndices repairs duplicate rows when the underlying repository
+// supports it; see interfaces.IndexDeduplicator.
+func (v *KeywordsVectorHybridRetrieveEngineService) DeduplicateIndices(ctx context.Context) (*types.DedupReport, error) {
+	deduplicator, ok := v.indexRepository.(interfaces.IndexDeduplicator)
+	if !ok {
+		return nil, ErrDedupUnsupported
+	}
+	defer v.cache.invalidateEngine(ctx, v.engineType)
+	return deduplicator.DeduplicateIndices(ctx)
+}
+
+// ValidateFilter compiles a filter condition when the underlying repository
+// supports it; see interfaces.FilterValidator.
+func (v *KeywordsVectorHybridRetrieveEngineService) ValidateFilter(
+	ctx context.Context, filter json.RawMessage,
+) (*types.FilterValidation, error) {
+	validator, ok := v.indexRepository.(interfaces.FilterValidator)
+	if !ok {
+		return nil, ErrFilterValidationUnsupported
+	}
+	return validator.ValidateFilter(ctx, filter)
+}
+
+// LoadStates reports collection load progress when the underlying
+// repository loads collections; see interfaces.LoadStateReporter.
+func (v *KeywordsVectorHybridRetrieveEngineService) LoadStates(ctx context.Context) (*types.LoadStateReport, error) {
+	reporter, ok := v.indexRepository.(interfaces.LoadStateReporter)
+	if !ok {
+		return nil, ErrLoadStateUnsupported
+	}
+	return reporter.LoadStates(ctx)
+}
+
+// ExportIndices streams the rows of a knowledge base with their vectors when
+// the underlying repository supports it; see interfaces.IndexExporter.
+func (v *KeywordsVectorHybridRetrieveEngineService) ExportIndices(ctx context.Context,
+	knowledgeBaseID string, batchSize int, fn func([]*types.ExportedIndex) error,
+) error {
+	exporter, ok := v.indexRepository.(interfaces.IndexExporter)
+	if !ok {
+		return ErrExportUnsupported
+	}
+	return exporter.ExportIndices(ctx, knowledgeBaseID, batchSize, fn)
+}
+
+// MoveIndices reassigns rows to another knowledge base when the underlying
+// repository supports it; see interfaces.IndexMover.
+func (v *KeywordsVectorHybridRetrieveEngineService) MoveIndices(ctx context.Context,
+	knowledgeIDs []string, targetKnowledgeBaseID string, chunkTagMap map[string]string,
+) error {
+	mover, ok := v.indexRepository.(interfaces.IndexMover)
+	if !ok {
+		return ErrMoveUnsupported
+	}
+	defer v.cache.invalidateEngine(ctx, v.engineType)
+	return mover.MoveIndices(ctx, knowledgeIDs, targetKnowledgeBaseID, chunkTagMap)
+}
+
+// Capabilities reports what the underlying repository supports; see
+// interfaces.CapabilityReporter.
+func (v *KeywordsVectorHybridRetrieveEngineService) Capabilities() types.EngineCapabilities {
+	return v.indexRepository.Capabilities()
+}
+
+// MigrateModelSpaces moves legacy rows into per-model collections when the
+// underlying repository supports it; see interfaces.ModelSpaceMigrator.
+func (v *KeywordsVectorHybridRetrieveEngineService) MigrateModelSpaces(
+	ctx context.Context, kbModels map[string]string,
+) (*types.ModelSpaceMigrationReport, error) {
+	migrator, ok := v.indexRepository.(interfaces.ModelSpaceMigrator)
+	if !ok {
+		return nil, ErrModelSpaceMigrationUnsupported
+	}
+	defer v.cache.invalidateEngine(ctx, v.engineType)
+	return migrator.MigrateModelSpaces(ctx, kbModels)
+}
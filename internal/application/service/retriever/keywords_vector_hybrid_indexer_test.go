@@ -2,7 +2,9 @@ package retriever
 
 import (
 	"context"
+	"fmt"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/Tencent/WeKnora/internal/models/embedding"
@@ -21,6 +23,10 @@ func (e *capturingEmbedder) Embed(ctx context.Context, text string) ([]float32,
 	return []float32{1}, nil
 }
 
+func (e *capturingEmbedder) GetModelID() string {
+	return "model-1"
+}
+
 func (e *capturingEmbedder) BatchEmbedWithPool(
 	ctx context.Context,
 	model embedding.Embedder,
@@ -107,6 +113,82 @@ func TestBatchIndexTruncatesOversizedEmbeddingInput(t *testing.T) {
 	}
 }
 
+func TestIndexStampsEmbeddingModelID(t *testing.T) {
+	ctx := context.Background()
+	embedder := &capturingEmbedder{}
+	service := &KeywordsVectorHybridRetrieveEngineService{indexRepository: &saveOnlyRepository{}}
+
+	single := &types.IndexInfo{Content: "a", SourceID: "source-1"}
+	if err := service.Index(ctx, embedder, single, []types.RetrieverType{types.VectorRetrieverType}); err != nil {
+		t.Fatalf("Index returned error: %v", err)
+	}
+	if single.EmbeddingModelID != "model-1" {
+		t.Fatalf("Index did not stamp model ID, got %q", single.EmbeddingModelID)
+	}
+
+	batch := []*types.IndexInfo{
+		{Content: "b", SourceID: "source-2"},
+		{Content: "c", SourceID: "source-3", EmbeddingModelID: "explicit"},
+	}
+	if err := service.BatchIndex(ctx, embedder, batch, []types.RetrieverType{types.VectorRetrieverType}); err != nil {
+		t.Fatalf("BatchIndex returned error: %v", err)
+	}
+	if batch[0].EmbeddingModelID != "model-1" || batch[1].EmbeddingModelID != "explicit" {
+		t.Fatalf("unexpected model IDs: %q, %q", batch[0].EmbeddingModelID, batch[1].EmbeddingModelID)
+	}
+}
+
+// embeddingCapturingRepository records the vectors passed to BatchSave.
+type embeddingCapturingRepository struct {
+	saveOnlyRepository
+	mu    sync.Mutex
+	saved map[string][]float32
+}
+
+func (r *embeddingCapturingRepository) BatchSave(
+	ctx context.Context,
+	indexInfoList []*types.IndexInfo,
+	params map[string]any,
+) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	embeddings, _ := params["embedding"].(map[string][]float32)
+	for _, info := range indexInfoList {
+		r.saved[info.SourceID] = embeddings[info.SourceID]
+	}
+	return nil
+}
+
+func TestBatchIndexEmbeddedSavesSuppliedVectors(t *testing.T) {
+	ctx := context.Background()
+	repo := &embeddingCapturingRepository{saved: map[string][]float32{}}
+	service := &KeywordsVectorHybridRetrieveEngineService{indexRepository: repo}
+
+	var infos []*types.IndexInfo
+	vectors := map[string][]float32{}
+	for i := 0; i < 95; i++ {
+		id := fmt.Sprintf("chunk-%d", i)
+		infos = append(infos, &types.IndexInfo{Content: id, SourceID: id})
+		vectors[id] = []float32{float32(i)}
+	}
+	if err := service.BatchIndexEmbedded(ctx, "model-1", infos, vectors,
+		[]types.RetrieverType{types.VectorRetrieverType}); err != nil {
+		t.Fatalf("BatchIndexEmbedded returned error: %v", err)
+	}
+	if len(repo.saved) != 95 || repo.saved["chunk-57"][0] != 57 {
+		t.Fatalf("saved %d rows, chunk-57 = %v", len(repo.saved), repo.saved["chunk-57"])
+	}
+	if infos[0].EmbeddingModelID != "model-1" {
+		t.Fatalf("model ID not stamped: %q", infos[0].EmbeddingModelID)
+	}
+
+	delete(vectors, "chunk-3")
+	if err := service.BatchIndexEmbedded(ctx, "model-1", infos, vectors,
+		[]types.RetrieverType{types.VectorRetrieverType}); err == nil {
+		t.Fatal("expected an error for a row without a vector")
+	}
+}
+
 func assertImagePayloadRemoved(t *testing.T, content string, payload string) {
 	t.Helper()
 	if strings.Contains(content, "data:image/png;base64") || strings.Contains(content, payload) {
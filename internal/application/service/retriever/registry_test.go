@@ -45,6 +45,12 @@ func (m *mockEngineService) DeleteBySourceIDList(_ context.Context, _ []string,
 func (m *mockEngineService) DeleteByKnowledgeIDList(_ context.Context, _ []string, _ int, _ string) error {
 	return nil
 }
+func (m *mockEngineService) DeleteByKnowledgeBaseIDList(_ context.Context, _ []string, _ int, _ string) error {
+	return nil
+}
+func (m *mockEngineService) DeleteByTagIDList(_ context.Context, _ []string, _ int, _ string) error {
+	return nil
+}
 func (m *mockEngineService) BatchUpdateChunkEnabledStatus(_ context.Context, _ map[string]bool) error {
 	return nil
 }
@@ -0,0 +1,376 @@
+package retriever
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Retrieval result caching.
+//
+// Identical retrievals (same engine, same query embedding, same KB scope)
+// are common within a session: regenerated answers, follow-up turns that
+// rewrite to the same query, parallel searches over the same KBs. The cache
+// sits in front of RetrieveEngineRepository.Retrieve and is disabled unless
+// a TTL is configured:
+//
+//	WEKNORA_RETRIEVE_CACHE_TTL          entry lifetime, e.g. "60s" (default 0 = disabled)
+//	WEKNORA_RETRIEVE_CACHE_MAX_ENTRIES  in-process LRU capacity (default 2000)
+//	WEKNORA_RETRIEVE_CACHE_BACKEND      "memory" (default) or "redis"
+//
+// Invalidation is generation based. Every key embeds the current generation
+// of each knowledge base in the request plus one per engine type; writes bump
+// the generation of the knowledge bases they touch, or of the whole engine
+// type when the write is addressed by chunk/source/tag IDs only. Superseded
+// entries become unreachable and age out through the TTL or LRU.
+//
+// The memory backend is per process; use the redis backend when several
+// instances index into and query the same stores. With redis, capacity is
+// bounded by the server's maxmemory policy instead of MAX_ENTRIES.
+
+const (
+	defaultRetrieveCacheMaxEntries = 2000
+	retrieveCacheRedisPrefix       = "weknora:retrieve_cache:"
+)
+
+// retrieveCacheBackend stores serialized results and generation counters.
+type retrieveCacheBackend interface {
+	get(ctx context.Context, key string) ([]byte, bool)
+	set(ctx context.Context, key string, value []byte)
+	generations(ctx context.Context, scopes []string) ([]int64, error)
+	bump(ctx context.Context, scopes []string)
+}
+
+// retrieveCache caches RetrieveResults per (engine type, params hash).
+type retrieveCache struct {
+	backend retrieveCacheBackend
+}
+
+// cachedRetrieveResult is the serialized form of a RetrieveResult; Error is
+// never cached.
+type cachedRetrieveResult struct {
+	Results             []*types.IndexWithScore   `json:"results"`
+	RetrieverEngineType types.RetrieverEngineType `json:"retriever_engine_type"`
+	RetrieverType       types.RetrieverType       `json:"retriever_type"`
+}
+
+var (
+	sharedRetrieveCache     *retrieveCache
+	sharedRetrieveCacheOnce sync.Once
+	retrieveCacheRedis      *redis.Client
+)
+
+// ConfigureRetrieveCache hands the Redis client to the retrieval cache. It
+// must be called before engines are built; rdb may be nil, in which case a
+// redis backend request falls back to memory.
+func ConfigureRetrieveCache(rdb *redis.Client) {
+	retrieveCacheRedis = rdb
+}
+
+// getRetrieveCache returns the process-wide cache, or nil when disabled.
+func getRetrieveCache() *retrieveCache {
+	sharedRetrieveCacheOnce.Do(func() {
+		ctx := context.Background()
+		v := strings.TrimSpace(os.Getenv("WEKNORA_RETRIEVE_CACHE_TTL"))
+		if v == "" || v == "0" {
+			return
+		}
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			logger.Warnf(ctx, "invalid WEKNORA_RETRIEVE_CACHE_TTL %q, retrieval cache disabled", v)
+			return
+		}
+		maxEntries := defaultRetrieveCacheMaxEntries
+		if v := strings.TrimSpace(os.Getenv("WEKNORA_RETRIEVE_CACHE_MAX_ENTRIES")); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				maxEntries = n
+			} else {
+				logger.Warnf(ctx, "invalid WEKNORA_RETRIEVE_CACHE_MAX_ENTRIES %q, using %d", v, maxEntries)
+			}
+		}
+
+		backend := strings.ToLower(strings.TrimSpace(os.Getenv("WEKNORA_RETRIEVE_CACHE_BACKEND")))
+		switch {
+		case backend == "redis" && retrieveCacheRedis != nil:
+			sharedRetrieveCache = &retrieveCache{backend: &redisRetrieveCache{client: retrieveCacheRedis, ttl: ttl}}
+		default:
+			if backend == "redis" {
+				logger.Warnf(ctx, "WEKNORA_RETRIEVE_CACHE_BACKEND=redis but Redis is not configured, using memory")
+			}
+			backend = "memory"
+			sharedRetrieveCache = &retrieveCache{backend: newMemoryRetrieveCache(ttl, maxEntries)}
+		}
+		logger.Infof(ctx, "[RetrieveCache] enabled: backend=%s ttl=%s max_entries=%d", backend, ttl, maxEntries)
+	})
+	return sharedRetrieveCache
+}
+
+func retrieveCacheEngineScope(engine types.RetrieverEngineType) string {
+	return "engine:" + string(engine)
+}
+
+func retrieveCacheKBScope(kbID string) string {
+	return "kb:" + kbID
+}
+
+// key returns the cache key for params, or "" when params cannot be cached.
+// Only KB-scoped retrievals are cached: without KB IDs a per-KB bump could
+// not reach the entry.
+func (c *retrieveCache) key(ctx context.Context, engine types.RetrieverEngineType, params types.RetrieveParams) string {
+	if len(params.KnowledgeBaseIDs) == 0 {
+		return ""
+	}
+	kbIDs := slices.Clone(params.KnowledgeBaseIDs)
+	slices.Sort(kbIDs)
+	kbIDs = slices.Compact(kbIDs)
+
+	scopes := make([]string, 0, len(kbIDs)+1)
+	scopes = append(scopes, retrieveCacheEngineScope(engine))
+	for _, id := range kbIDs {
+		scopes = append(scopes, retrieveCacheKBScope(id))
+	}
+	gens, err := c.backend.generations(ctx, scopes)
+	if err != nil {
+		logger.Warnf(ctx, "[RetrieveCache] read generations failed, bypassing cache: %v", err)
+		return ""
+	}
+
+	payload, err := json.Marshal(struct {
+		Engine      types.RetrieverEngineType `json:"engine"`
+		Params      types.RetrieveParams      `json:"params"`
+		Generations []int64                   `json:"generations"`
+	}{engine, params, gens})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// retrieve serves params from the cache or calls fetch and stores its result.
+func (c *retrieveCache) retrieve(ctx context.Context, engine types.RetrieverEngineType, params types.RetrieveParams,
+	fetch func() ([]*types.RetrieveResult, error),
+) ([]*types.RetrieveResult, error) {
+	key := c.key(ctx, engine, params)
+	if key == "" {
+		return fetch()
+	}
+	if raw, ok := c.backend.get(ctx, key); ok {
+		var cached []cachedRetrieveResult
+		if err := json.Unmarshal(raw, &cached); err == nil {
+			metrics.RecordCache(ctx, metrics.CacheRetrieve, true)
+			logger.Debugf(ctx, "[RetrieveCache] hit engine=%s retriever=%s", engine, params.RetrieverType)
+			results := make([]*types.RetrieveResult, len(cached))
+			for i, r := range cached {
+				results[i] = &types.RetrieveResult{
+					Results:             r.Results,
+					RetrieverEngineType: r.RetrieverEngineType,
+					RetrieverType:       r.RetrieverType,
+				}
+			}
+			return results, nil
+		}
+	}
+
+	metrics.RecordCache(ctx, metrics.CacheRetrieve, false)
+	results, err := fetch()
+	if err != nil {
+		return results, err
+	}
+	cached := make([]cachedRetrieveResult, 0, len(results))
+	for _, r := range results {
+		if r == nil || r.Error != nil {
+			return results, nil
+		}
+		cached = append(cached, cachedRetrieveResult{
+			Results:             r.Results,
+			RetrieverEngineType: r.RetrieverEngineType,
+			RetrieverType:       r.RetrieverType,
+		})
+	}
+	if raw, err := json.Marshal(cached); err == nil {
+		c.backend.set(ctx, key, raw)
+	}
+	return results, nil
+}
+
+// invalidateKBs drops cached results of the given knowledge bases.
+func (c *retrieveCache) invalidateKBs(ctx context.Context, kbIDs ...string) {
+	if c == nil || len(kbIDs) == 0 {
+		return
+	}
+	scopes := make([]string, 0, len(kbIDs))
+	for _, id := range kbIDs {
+		if id != "" {
+			scopes = append(scopes, retrieveCacheKBScope(id))
+		}
+	}
+	slices.Sort(scopes)
+	c.backend.bump(ctx, slices.Compact(scopes))
+}
+
+// invalidateEngine drops every cached result of the engine type.
+func (c *retrieveCache) invalidateEngine(ctx context.Context, engine types.RetrieverEngineType) {
+	if c == nil {
+		return
+	}
+	c.backend.bump(ctx, []string{retrieveCacheEngineScope(engine)})
+}
+
+type memoryRetrieveCacheEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// memoryRetrieveCache is a TTL-bounded LRU with in-process generations.
+type memoryRetrieveCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	order      *list.List // front = most recently used
+	entries    map[string]*list.Element
+	gens       map[string]int64
+	now        func() time.Time
+}
+
+func newMemoryRetrieveCache(ttl time.Duration, maxEntries int) *memoryRetrieveCache {
+	return &memoryRetrieveCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		gens:       make(map[string]int64),
+		now:        time.Now,
+	}
+}
+
+func (m *memoryRetrieveCache) get(_ context.Context, key string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	el, ok := m.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*memoryRetrieveCacheEntry)
+	if m.now().After(entry.expiresAt) {
+		m.order.Remove(el)
+		delete(m.entries, key)
+		return nil, false
+	}
+	m.order.MoveToFront(el)
+	return entry.value, true
+}
+
+func (m *memoryRetrieveCache) set(_ context.Context, key string, value []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if el, ok := m.entries[key]; ok {
+		entry := el.Value.(*memoryRetrieveCacheEntry)
+		entry.value = value
+		entry.expiresAt = m.now().Add(m.ttl)
+		m.order.MoveToFront(el)
+		return
+	}
+	m.entries[key] = m.order.PushFront(&memoryRetrieveCacheEntry{
+		key:       key,
+		value:     value,
+		expiresAt: m.now().Add(m.ttl),
+	})
+	for m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryRetrieveCacheEntry).key)
+	}
+}
+
+func (m *memoryRetrieveCache) generations(_ context.Context, scopes []string) ([]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	gens := make([]int64, len(scopes))
+	for i, s := range scopes {
+		gens[i] = m.gens[s]
+	}
+	return gens, nil
+}
+
+func (m *memoryRetrieveCache) bump(_ context.Context, scopes []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range scopes {
+		m.gens[s]++
+	}
+}
+
+// redisRetrieveCache shares entries and generations across instances.
+type redisRetrieveCache struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func (r *redisRetrieveCache) get(ctx context.Context, key string) ([]byte, bool) {
+	raw, err := r.client.Get(ctx, retrieveCacheRedisPrefix+"entry:"+key).Bytes()
+	if err != nil {
+		if err != redis.Nil {
+			logger.Warnf(ctx, "[RetrieveCache] redis get failed: %v", err)
+		}
+		return nil, false
+	}
+	return raw, true
+}
+
+func (r *redisRetrieveCache) set(ctx context.Context, key string, value []byte) {
+	if err := r.client.Set(ctx, retrieveCacheRedisPrefix+"entry:"+key, value, r.ttl).Err(); err != nil {
+		logger.Warnf(ctx, "[RetrieveCache] redis set failed: %v", err)
+	}
+}
+
+func (r *redisRetrieveCache) generations(ctx context.Context, scopes []string) ([]int64, error) {
+	keys := make([]string, len(scopes))
+	for i, s := range scopes {
+		keys[i] = retrieveCacheRedisPrefix + "gen:" + s
+	}
+	vals, err := r.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	gens := make([]int64, len(vals))
+	for i, v := range vals {
+		if s, ok := v.(string); ok {
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("generation %s: %w", keys[i], err)
+			}
+			gens[i] = n
+		}
+	}
+	return gens, nil
+}
+
+func (r *redisRetrieveCache) bump(ctx context.Context, scopes []string) {
+	if len(scopes) == 0 {
+		return
+	}
+	pipe := r.client.Pipeline()
+	for _, s := range scopes {
+		pipe.Incr(ctx, retrieveCacheRedisPrefix+"gen:"+s)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.Warnf(ctx, "[RetrieveCache] redis invalidation failed: %v", err)
+	}
+}
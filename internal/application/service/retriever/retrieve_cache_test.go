@@ -0,0 +1,108 @@
+package retriever
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+type countingRetrieveRepository struct {
+	interfaces.RetrieveEngineRepository
+	retrieves int
+}
+
+func (r *countingRetrieveRepository) Retrieve(
+	ctx context.Context, params types.RetrieveParams,
+) ([]*types.RetrieveResult, error) {
+	r.retrieves++
+	return []*types.RetrieveResult{{
+		Results:             []*types.IndexWithScore{{ChunkID: "c1", KnowledgeBaseID: "kb-1", Score: 0.9}},
+		RetrieverEngineType: types.MilvusRetrieverEngineType,
+		RetrieverType:       types.VectorRetrieverType,
+	}}, nil
+}
+
+func (r *countingRetrieveRepository) DeleteByKnowledgeBaseIDList(ctx context.Context, ids []string, dim int, kt string) error {
+	return nil
+}
+
+func (r *countingRetrieveRepository) DeleteByChunkIDList(ctx context.Context, ids []string, dim int, kt string) error {
+	return nil
+}
+
+func newCachedTestEngine() (*KeywordsVectorHybridRetrieveEngineService, *countingRetrieveRepository) {
+	repo := &countingRetrieveRepository{}
+	return &KeywordsVectorHybridRetrieveEngineService{
+		indexRepository: repo,
+		engineType:      types.MilvusRetrieverEngineType,
+		cache:           &retrieveCache{backend: newMemoryRetrieveCache(time.Minute, 10)},
+	}, repo
+}
+
+func TestRetrieveCacheHitReturnsCopy(t *testing.T) {
+	ctx := context.Background()
+	engine, repo := newCachedTestEngine()
+	params := types.RetrieveParams{Query: "q", KnowledgeBaseIDs: []string{"kb-1"}, TopK: 5}
+
+	first, _ := engine.Retrieve(ctx, params)
+	first[0].Results[0].Score = 0 // callers normalize scores in place
+	second, err := engine.Retrieve(ctx, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.retrieves != 1 {
+		t.Fatalf("got %d repository calls, want 1", repo.retrieves)
+	}
+	if second[0].Results[0].Score != 0.9 {
+		t.Fatalf("cached result was mutated by caller: score=%v", second[0].Results[0].Score)
+	}
+
+	// Every retrieve parameter is part of the key.
+	_, _ = engine.Retrieve(ctx, types.RetrieveParams{Query: "q", KnowledgeBaseIDs: []string{"kb-1"}, TopK: 6})
+	if repo.retrieves != 2 {
+		t.Fatalf("TopK not part of the key: %d repository calls", repo.retrieves)
+	}
+}
+
+func TestRetrieveCacheInvalidatedByWrites(t *testing.T) {
+	ctx := context.Background()
+	engine, repo := newCachedTestEngine()
+	kb1 := types.RetrieveParams{Query: "q", KnowledgeBaseIDs: []string{"kb-1"}}
+	kb2 := types.RetrieveParams{Query: "q", KnowledgeBaseIDs: []string{"kb-2"}}
+
+	_, _ = engine.Retrieve(ctx, kb1)
+	_, _ = engine.Retrieve(ctx, kb2)
+	if err := engine.DeleteByKnowledgeBaseIDList(ctx, []string{"kb-1"}, 768, ""); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = engine.Retrieve(ctx, kb1)
+	_, _ = engine.Retrieve(ctx, kb2)
+	if repo.retrieves != 3 {
+		t.Fatalf("per-KB invalidation: got %d repository calls, want 3", repo.retrieves)
+	}
+
+	// Chunk-addressed deletes cannot be mapped to a KB and reset the engine.
+	if err := engine.DeleteByChunkIDList(ctx, []string{"c1"}, 768, ""); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = engine.Retrieve(ctx, kb1)
+	_, _ = engine.Retrieve(ctx, kb2)
+	if repo.retrieves != 5 {
+		t.Fatalf("engine invalidation: got %d repository calls, want 5", repo.retrieves)
+	}
+}
+
+func TestRetrieveCacheSkipsUnscopedQueries(t *testing.T) {
+	ctx := context.Background()
+	engine, repo := newCachedTestEngine()
+	params := types.RetrieveParams{Query: "q", KnowledgeIDs: []string{"k1"}}
+
+	_, _ = engine.Retrieve(ctx, params)
+	_, _ = engine.Retrieve(ctx, params)
+	if repo.retrieves != 2 {
+		t.Fatalf("got %d repository calls, want 2", repo.retrieves)
+	}
+}
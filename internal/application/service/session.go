@@ -47,6 +47,7 @@ type sessionService struct {
 	webSearchProviderRepo interfaces.WebSearchProviderRepository // Repository for web search provider entities
 	kbShareService        interfaces.KBShareService              // Service for KB sharing operations
 	memoryService         interfaces.MemoryService               // Service for memory operations
+	tagScopeService       interfaces.TagScopeService             // Service for tag hierarchy and access scoping
 }
 
 // NewSessionService creates a new session service instance with all required dependencies
@@ -64,6 +65,7 @@ func NewSessionService(cfg *config.Config,
 	webSearchProviderRepo interfaces.WebSearchProviderRepository,
 	kbShareService interfaces.KBShareService,
 	memoryService interfaces.MemoryService,
+	tagScopeService interfaces.TagScopeService,
 ) interfaces.SessionService {
 	return &sessionService{
 		cfg:                   cfg,
@@ -80,6 +82,7 @@ func NewSessionService(cfg *config.Config,
 		webSearchProviderRepo: webSearchProviderRepo,
 		kbShareService:        kbShareService,
 		memoryService:         memoryService,
+		tagScopeService:       tagScopeService,
 	}
 }
 
@@ -514,7 +517,7 @@ func (s *sessionService) GenerateTitle(ctx context.Context,
 
 	// Call model to generate title
 	thinking := false
-	response, err := chatModel.Chat(ctx, chatMessages, &chat.ChatOptions{
+	response, err := chatModel.Chat(chat.WithResponseCache(ctx, "session_title"), chatMessages, &chat.ChatOptions{
 		Temperature: 0.3,
 		Thinking:    &thinking,
 	})
@@ -89,6 +89,15 @@ func (s *sessionService) AgentQA(
 		return fmt.Errorf("failed to get chat model: %w", err)
 	}
 
+	// Self-hosted servers report the context length they were started with;
+	// never budget more context than the model can take.
+	if contextLength := chat.ContextLength(ctx, summaryModel); contextLength > 0 &&
+		contextLength < agentConfig.MaxContextTokens {
+		logger.Infof(ctx, "Limiting agent context to %d tokens reported by model %s (configured %d)",
+			contextLength, summaryModel.GetModelName(), agentConfig.MaxContextTokens)
+		agentConfig.MaxContextTokens = contextLength
+	}
+
 	// Get rerank model from custom agent config only when knowledge_search can
 	// actually run. A disabled KB scope makes all KB tools ineffective, so it
 	// must not force users to configure an otherwise-unused rerank model.
@@ -230,6 +239,7 @@ func (s *sessionService) buildAgentConfig(
 		Thinking:                    customAgent.Config.Thinking,
 		RetrieveKBOnlyWhenMentioned: customAgent.Config.RetrieveKBOnlyWhenMentioned,
 		LLMCallTimeout:              customAgent.Config.LLMCallTimeout,
+		RunTimeout:                  customAgent.Config.RunTimeout,
 		RetainRetrievalHistory:      customAgent.Config.RetainRetrievalHistory,
 	}
 
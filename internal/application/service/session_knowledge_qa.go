@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -108,6 +109,8 @@ func (s *sessionService) KnowledgeQA(
 			SessionID:               req.Session.ID,
 			UserID:                  userID,
 			EnableMemory:            req.EnableMemory,
+			MemoryPolicy:            req.Session.MemoryPolicy,
+			Remember:                req.Remember,
 			MaxRounds:               s.cfg.Conversation.MaxRounds,
 			KnowledgeBaseIDs:        knowledgeBaseIDs,
 			KnowledgeIDs:            knowledgeIDs,
@@ -154,10 +157,44 @@ func (s *sessionService) KnowledgeQA(
 	// rewrite, fallback, FAQ strategy, history turns)
 	s.applyAgentOverridesToChatManage(ctx, req.CustomAgent, chatManage)
 
+	// The tenant's pipeline config applies over the agent's settings, and the
+	// session's rerank and pipeline configs over both
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant != nil {
+		tenant.PipelineConfig.ApplyTo(&chatManage.PipelineRequest)
+	}
+	if rc := req.Session.RerankConfig; rc != nil {
+		if rc.Disabled() {
+			chatManage.RerankModelID = ""
+		}
+		if rc.TopN > 0 {
+			chatManage.RerankTopK = rc.TopN
+		}
+	}
+	req.Session.PipelineConfig.ApplyTo(&chatManage.PipelineRequest)
+	if effective, err := json.Marshal(types.EffectivePipelineConfig(&chatManage.PipelineRequest)); err == nil {
+		logger.Infof(ctx, "Effective pipeline config for session %s: %s", req.Session.ID, effective)
+	}
+	if req.Debug {
+		ctx, _ = types.WithRetrievalTrace(ctx)
+		chatManage.Trace = chatpipeline.NewPipelineTrace(chatManage)
+	}
+
+	if tenant != nil {
+		chatManage.Guardrails = tenant.GuardrailsConfig
+	}
+	guardrails := chatManage.Guardrails.Active()
+
 	// Determine pipeline based on knowledge bases availability and web search setting
 	hasKB := len(knowledgeBaseIDs) > 0 || len(knowledgeIDs) > 0
-	needsRAG := hasKB || req.WebSearchEnabled
+	hasSearch := hasKB || req.WebSearchEnabled
+	text2SQL := len(chatManage.SQLConnectionIDs) > 0
+	needsRAG := hasSearch || text2SQL
 	hasHistory := chatManage.MaxRounds > 0
+	// Questions that @mention knowledge bases or files search exactly those.
+	if len(req.KnowledgeBaseIDs) > 0 || len(req.KnowledgeIDs) > 0 || len(req.TagScopes) > 0 {
+		chatManage.KBAutoRoute = false
+	}
 
 	var pipeline []types.EventType
 	if !needsRAG {
@@ -176,29 +213,39 @@ func (s *sessionService) KnowledgeQA(
 		chatManage.UserContent = userContent
 
 		pipeline = types.NewPipelineBuilder().
+			AddIf(guardrails, types.GUARDRAILS_INPUT).
 			AddIf(hasHistory, types.LOAD_HISTORY).
 			AddIf(chatManage.EnableMemory, types.MEMORY_RETRIEVAL).
+			Add(types.CONTEXT_PACK).
 			Add(types.CHAT_COMPLETION_STREAM).
 			AddIf(chatManage.EnableMemory, types.MEMORY_STORAGE).
 			Build()
 	} else {
 		// RAG — dynamically assemble based on feature flags.
 		pipeline = types.NewPipelineBuilder().
+			AddIf(guardrails, types.GUARDRAILS_INPUT).
 			AddIf(hasHistory, types.LOAD_HISTORY).
 			Add(types.QUERY_UNDERSTAND).
-			Add(types.CHUNK_SEARCH_PARALLEL).
-			Add(types.CHUNK_RERANK).
+			AddIf(chatManage.EnableMemory, types.MEMORY_QUERY_REWRITE).
+			AddIf(text2SQL, types.TEXT2SQL).
+			AddIf(chatManage.KBAutoRoute && hasKB, types.KB_ROUTE).
+			AddIf(chatManage.MultiQueryEnabled && hasKB, types.QUERY_VARIANTS).
+			AddIf(hasSearch, types.CHUNK_SEARCH_PARALLEL).
+			AddIf(hasSearch, types.CHUNK_RERANK).
 			AddIf(req.WebSearchEnabled, types.WEB_FETCH).
-			Add(types.CHUNK_MERGE).
-			Add(types.FILTER_TOP_K).
+			AddIf(hasSearch, types.CHUNK_MERGE).
+			AddIf(hasSearch, types.FILTER_TOP_K).
+			AddIf(hasKB, types.CHUNK_CONTEXT_WINDOW).
 			AddIf(chatManage.DataAnalysisEnabled, types.DATA_ANALYSIS).
+			AddIf(guardrails, types.GUARDRAILS_CONTEXT).
+			Add(types.CONTEXT_PACK).
 			Add(types.INTO_CHAT_MESSAGE).
 			Add(types.CHAT_COMPLETION_STREAM).
 			Build()
 	}
 
-	logger.Infof(ctx, "Assembled pipeline (%d stages), hasKB=%v, webSearch=%v, history=%v",
-		len(pipeline), hasKB, req.WebSearchEnabled, hasHistory)
+	logger.Infof(ctx, "Assembled pipeline (%d stages), hasKB=%v, webSearch=%v, text2sql=%v, history=%v",
+		len(pipeline), hasKB, req.WebSearchEnabled, text2SQL, hasHistory)
 
 	// Start knowledge QA event processing (set session tenant so pipeline session/message lookups use session owner)
 	ctx = context.WithValue(ctx, types.SessionTenantIDContextKey, req.Session.TenantID)
@@ -482,7 +529,7 @@ func (s *sessionService) buildSearchTargets(
 		knowledgeList, err := s.knowledgeService.GetKnowledgeBatchWithSharedAccess(ctx, tenantID, knowledgeIDs)
 		if err != nil {
 			logger.Warnf(ctx, "Failed to get knowledge batch for search targets: %v", err)
-			return targets, nil // Return what we have, don't fail
+			return s.scopeSearchTargets(ctx, targets) // Return what we have, don't fail
 		}
 
 		// Group knowledge IDs by their KB, excluding those already covered by full KB search
@@ -569,7 +616,16 @@ func (s *sessionService) buildSearchTargets(
 	logger.Infof(ctx, "Built %d search targets: %d full KB, %d partial/tag KB, kbTenantMap=%v",
 		len(targets), len(knowledgeBaseIDs), len(targets)-len(knowledgeBaseIDs), kbTenantMap)
 
-	return targets, nil
+	return s.scopeSearchTargets(ctx, targets)
+}
+
+// scopeSearchTargets expands tag filters to their descendant tags and applies
+// the caller's tag access rules.
+func (s *sessionService) scopeSearchTargets(ctx context.Context, targets types.SearchTargets) (types.SearchTargets, error) {
+	if s.tagScopeService == nil {
+		return targets, nil
+	}
+	return s.tagScopeService.ScopeSearchTargets(ctx, targets)
 }
 
 // KnowledgeQAByEvent processes knowledge QA through a series of events in the pipeline
@@ -631,6 +687,7 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 		// already closed the stream, so the frontend only saw citations on refresh.
 		if eventType == types.CHAT_COMPLETION_STREAM {
 			emitKnowledgeReferencesEvent(ctx, chatManage)
+			chatpipeline.EmitPipelineTrace(ctx, chatManage)
 		}
 		err := s.eventManager.Trigger(stageCtx, eventType, chatManage)
 		if understandProgress != nil && eventType == types.QUERY_UNDERSTAND {
@@ -651,6 +708,7 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 				"duration_ms": stageDuration.Milliseconds(),
 			}, nil, spanErr)
 		}
+		chatpipeline.TraceStage(chatManage, eventType, stageDuration, err)
 
 		// If the user stopped generation, the context is cancelled. A cancelled
 		// retrieval stage surfaces as ErrSearchNothing (the search goroutines
@@ -670,6 +728,19 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 			return ctxErr
 		}
 
+		if err == chatpipeline.ErrGuardrailBlocked {
+			common.PipelineWarn(ctx, "Pipeline", "stage_blocked", map[string]interface{}{
+				"event":       string(eventType),
+				"duration_ms": stageDuration.Milliseconds(),
+				"detections":  len(chatManage.GuardrailDetections),
+			})
+			content := guardrailBlockedResponse(chatManage)
+			chatManage.ChatResponse = &types.ChatResponse{Content: content}
+			chatpipeline.EmitPipelineTrace(ctx, chatManage)
+			s.emitFallbackAnswer(ctx, chatManage, content)
+			return nil
+		}
+
 		if err == chatpipeline.ErrSearchNothing {
 			common.PipelineWarn(ctx, "Pipeline", "stage_fallback", map[string]interface{}{
 				"event":       string(eventType),
@@ -677,6 +748,7 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 				"reason":      "search_nothing",
 				"strategy":    string(chatManage.FallbackStrategy),
 			})
+			chatpipeline.EmitPipelineTrace(ctx, chatManage)
 			s.handleFallbackResponse(ctx, chatManage)
 			return nil
 		}
@@ -688,6 +760,7 @@ func (s *sessionService) KnowledgeQAByEvent(ctx context.Context,
 				"error_type":  err.ErrorType,
 				"description": err.Description,
 			})
+			chatpipeline.EmitPipelineTrace(ctx, chatManage)
 			return err.Err
 		}
 
@@ -828,6 +901,16 @@ func (s *sessionService) SearchKnowledge(ctx context.Context,
 
 // handleFallbackResponse handles fallback response based on strategy
 func (s *sessionService) handleFallbackResponse(ctx context.Context, chatManage *types.ChatManage) {
+	// When the search stage reported that retrieval itself was down, say so
+	// instead of the "no relevant knowledge" fallback: an LLM answer without
+	// context would be presented as grounded, and the fixed response would
+	// wrongly suggest the knowledge base has nothing on the topic.
+	if chatManage.RetrievalStatus.Unavailable() {
+		content := retrievalUnavailableResponse(chatManage.Language)
+		chatManage.ChatResponse = &types.ChatResponse{Content: content}
+		s.emitFallbackAnswer(ctx, chatManage, content)
+		return
+	}
 	if chatManage.FallbackStrategy == types.FallbackStrategyModel {
 		s.handleModelFallback(ctx, chatManage)
 	} else {
@@ -835,6 +918,27 @@ func (s *sessionService) handleFallbackResponse(ctx context.Context, chatManage
 	}
 }
 
+// retrievalUnavailableResponse returns the honest answer used when the
+// degradation policy reported retrieval as unavailable.
+func retrievalUnavailableResponse(lang string) string {
+	if types.LanguageLocaleName(lang) == "Chinese (Simplified)" || lang == "" {
+		return "知识库检索服务暂时不可用，暂时无法基于知识库回答该问题，请稍后重试。"
+	}
+	return "Knowledge base retrieval is temporarily unavailable, so this question cannot be answered from the knowledge base right now. Please try again later."
+}
+
+// guardrailBlockedResponse returns the answer given to a question the
+// guardrails stage blocked.
+func guardrailBlockedResponse(chatManage *types.ChatManage) string {
+	if chatManage.Guardrails != nil && chatManage.Guardrails.BlockResponse != "" {
+		return chatManage.Guardrails.BlockResponse
+	}
+	if types.LanguageLocaleName(chatManage.Language) == "Chinese (Simplified)" || chatManage.Language == "" {
+		return "抱歉，该问题触发了安全策略，无法回答。"
+	}
+	return "Sorry, this question was blocked by the content safety policy and cannot be answered."
+}
+
 // handleFixedFallback handles fixed fallback response
 func (s *sessionService) handleFixedFallback(ctx context.Context, chatManage *types.ChatManage) {
 	fallbackContent := chatManage.FallbackResponse
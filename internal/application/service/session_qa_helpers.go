@@ -202,10 +202,14 @@ func (s *sessionService) applyAgentOverridesToChatManage(
 	if customAgent.Config.RerankModelID != "" {
 		cm.RerankModelID = customAgent.Config.RerankModelID
 	}
+	cm.RerankFallbackModelIDs = customAgent.Config.RerankFallbackModelIDs
 
 	// Override rewrite settings
 	cm.EnableRewrite = customAgent.Config.EnableRewrite
 	cm.EnableQueryExpansion = customAgent.Config.EnableQueryExpansion
+	cm.MultiQueryEnabled = customAgent.Config.MultiQueryEnabled
+	cm.MultiQueryCount = customAgent.Config.MultiQueryCount
+	cm.MultiQueryStrategies = customAgent.Config.MultiQueryStrategies
 	if customAgent.Config.RewritePromptSystem != "" {
 		cm.RewritePromptSystem = customAgent.Config.RewritePromptSystem
 	}
@@ -228,6 +232,14 @@ func (s *sessionService) applyAgentOverridesToChatManage(
 	if customAgent.Config.FallbackPrompt != "" {
 		cm.FallbackPrompt = customAgent.Config.FallbackPrompt
 	}
+	cm.DisableCitations = customAgent.Config.DisableCitations
+	cm.AnswerVerificationEnabled = customAgent.Config.AnswerVerificationEnabled
+	cm.AnswerVerificationAction = customAgent.Config.AnswerVerificationAction
+	cm.FollowUpQuestionsEnabled = customAgent.Config.FollowUpQuestionsEnabled
+	cm.ContextTokenBudget = customAgent.Config.ContextTokenBudget
+	cm.ContextBudgetRatios = customAgent.Config.ContextBudgetRatios
+	cm.KBAutoRoute = customAgent.Config.KBAutoRoute
+	cm.KBRouteMaxKBs = customAgent.Config.KBRouteMaxKBs
 
 	// Override web search settings
 	if customAgent.Config.WebSearchMaxResults > 0 {
@@ -259,6 +271,12 @@ func (s *sessionService) applyAgentOverridesToChatManage(
 		logger.Infof(ctx, "Data analysis pipeline stage enabled by custom agent")
 	}
 
+	// Text2SQL pipeline stage (opt-in, needs at least one SQL connection).
+	if customAgent.Config.Text2SQLEnabled && len(customAgent.Config.SQLConnectionIDs) > 0 {
+		cm.SQLConnectionIDs = customAgent.Config.SQLConnectionIDs
+		logger.Infof(ctx, "Text2SQL pipeline stage enabled by custom agent (%d connections)", len(cm.SQLConnectionIDs))
+	}
+
 	if len(customAgent.Config.IntentPrompts) > 0 {
 		cm.IntentPromptOverrides = customAgent.Config.IntentPrompts
 		logger.Infof(ctx, "Using custom agent's intent_prompts (%d overrides)", len(cm.IntentPromptOverrides))
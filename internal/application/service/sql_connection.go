@@ -0,0 +1,494 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib" // pgx driver for database/sql
+)
+
+const (
+	// sqlSchemaCacheTTL is how long a described schema is reused before the
+	// database is asked again.
+	sqlSchemaCacheTTL = 10 * time.Minute
+	// maxSchemaTables caps the tables described to the model.
+	maxSchemaTables = 100
+	// maxSQLCellLength caps a single result cell fed into the answer.
+	maxSQLCellLength = 200
+	// maxGeneratedSQLLength caps the SQL accepted for execution.
+	maxGeneratedSQLLength = 8192
+)
+
+// sqlPool is an open database handle for one version of a connection.
+type sqlPool struct {
+	db        *sql.DB
+	updatedAt time.Time
+	// refs counts the requests using the pool and retired marks a pool
+	// replaced or removed from the cache; it is closed once both hold.
+	// Both are guarded by sqlConnectionService.poolMu.
+	refs    int
+	retired bool
+
+	mu         sync.Mutex
+	schema     string
+	describeAt time.Time
+}
+
+// sqlConnectionService implements interfaces.SQLConnectionService
+type sqlConnectionService struct {
+	repo interfaces.SQLConnectionRepository
+	// poolMu guards pools, so concurrent requests share one pool and a
+	// pool is never closed under a request still using it
+	poolMu sync.Mutex
+	pools  map[string]*sqlPool // connection ID -> current pool
+}
+
+// NewSQLConnectionService creates a new SQL connection service
+func NewSQLConnectionService(repo interfaces.SQLConnectionRepository) interfaces.SQLConnectionService {
+	return &sqlConnectionService{repo: repo}
+}
+
+// CreateConnection validates and creates a new SQL connection.
+func (s *sqlConnectionService) CreateConnection(ctx context.Context, conn *types.SQLConnection) error {
+	if conn.TenantID == 0 {
+		return fmt.Errorf("tenant ID is required")
+	}
+	if err := validateSQLConnection(conn); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Creating SQL connection: tenant=%d, name=%s, driver=%s", conn.TenantID, conn.Name, conn.Driver)
+	return s.repo.Create(ctx, conn)
+}
+
+// GetConnection retrieves a connection by tenant + id.
+func (s *sqlConnectionService) GetConnection(ctx context.Context, tenantID uint64, id string) (*types.SQLConnection, error) {
+	return s.repo.GetByID(ctx, tenantID, id)
+}
+
+// ListConnections lists the connections of a tenant.
+func (s *sqlConnectionService) ListConnections(ctx context.Context, tenantID uint64) ([]*types.SQLConnection, error) {
+	return s.repo.List(ctx, tenantID)
+}
+
+// UpdateConnection validates and updates an existing connection. The pool
+// of the old version is closed so the next query reconnects.
+func (s *sqlConnectionService) UpdateConnection(ctx context.Context, conn *types.SQLConnection) error {
+	if conn.TenantID == 0 {
+		return fmt.Errorf("tenant ID is required")
+	}
+	existing, err := s.repo.GetByID(ctx, conn.TenantID, conn.ID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return errors.NewNotFoundError("sql connection not found")
+	}
+	if conn.Parameters.Password == "" {
+		conn.Parameters.Password = existing.Parameters.Password
+	}
+	if err := validateSQLConnection(conn); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "Updating SQL connection: tenant=%d, id=%s", conn.TenantID, conn.ID)
+	if err := s.repo.Update(ctx, conn); err != nil {
+		return err
+	}
+	s.closePool(conn.ID)
+	return nil
+}
+
+// DeleteConnection deletes a connection by tenant + id.
+func (s *sqlConnectionService) DeleteConnection(ctx context.Context, tenantID uint64, id string) error {
+	logger.Infof(ctx, "Deleting SQL connection: tenant=%d, id=%s", tenantID, id)
+	if err := s.repo.Delete(ctx, tenantID, id); err != nil {
+		return err
+	}
+	s.closePool(id)
+	return nil
+}
+
+// TestConnection opens the database and pings it. The handle is not cached
+// so unsaved parameters can be tested.
+func (s *sqlConnectionService) TestConnection(ctx context.Context, conn *types.SQLConnection) error {
+	if err := validateSQLConnection(conn); err != nil {
+		return err
+	}
+	db, err := openSQLConnection(conn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	testCtx, cancel := context.WithTimeout(ctx, connectionTestTimeout)
+	defer cancel()
+	if err := db.PingContext(testCtx); err != nil {
+		logger.Warnf(ctx, "SQL connection test failed: %v", err)
+		return errors.NewBadRequestError(
+			fmt.Sprintf("failed to connect to %s: connection refused or authentication failed", conn.Driver))
+	}
+	return nil
+}
+
+// DescribeSchema lists the allowed tables with their columns and types as
+// one line per table, e.g. "orders(id integer, amount numeric)". The result
+// is cached per connection version for sqlSchemaCacheTTL.
+func (s *sqlConnectionService) DescribeSchema(ctx context.Context, conn *types.SQLConnection) (string, error) {
+	pool, release, err := s.acquirePool(conn)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if pool.schema != "" && time.Since(pool.describeAt) < sqlSchemaCacheTTL {
+		return pool.schema, nil
+	}
+
+	query := `SELECT table_name, column_name, data_type FROM information_schema.columns
+WHERE table_schema = current_schema() ORDER BY table_name, ordinal_position`
+	if conn.Driver == types.SQLDriverMySQL {
+		query = `SELECT table_name, column_name, data_type FROM information_schema.columns
+WHERE table_schema = DATABASE() ORDER BY table_name, ordinal_position`
+	}
+	queryCtx, cancel := context.WithTimeout(ctx, conn.EffectiveQueryTimeout())
+	defer cancel()
+	rows, err := pool.db.QueryContext(queryCtx, query)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to describe schema of SQL connection %s: %v", conn.ID, err)
+		return "", fmt.Errorf("failed to read database schema")
+	}
+	defer rows.Close()
+
+	var columns []schemaColumn
+	for rows.Next() {
+		var c schemaColumn
+		if err := rows.Scan(&c.table, &c.column, &c.dataType); err != nil {
+			return "", fmt.Errorf("failed to read database schema: %w", err)
+		}
+		columns = append(columns, c)
+	}
+	if err := rows.Err(); err != nil {
+		return "", fmt.Errorf("failed to read database schema: %w", err)
+	}
+
+	pool.schema = formatSchema(columns, conn.AllowedTables)
+	pool.describeAt = time.Now()
+	return pool.schema, nil
+}
+
+// ExecuteQuery validates sqlText and runs it in a read-only transaction that
+// is always rolled back. At most EffectiveMaxRows rows are read; Truncated
+// reports whether there were more.
+func (s *sqlConnectionService) ExecuteQuery(
+	ctx context.Context, conn *types.SQLConnection, sqlText string,
+) (*types.SQLQueryResult, error) {
+	sqlText = strings.TrimSuffix(strings.TrimSpace(sqlText), ";")
+	if err := validateGeneratedSQL(conn, sqlText); err != nil {
+		return nil, err
+	}
+	pool, release, err := s.acquirePool(conn)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	queryCtx, cancel := context.WithTimeout(ctx, conn.EffectiveQueryTimeout())
+	defer cancel()
+	tx, err := pool.db.BeginTx(queryCtx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start read-only transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(queryCtx, sqlText)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	result := &types.SQLQueryResult{SQL: sqlText, Columns: columns}
+	maxRows := conn.EffectiveMaxRows()
+	values := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if len(result.Rows) == maxRows {
+			result.Truncated = true
+			break
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to read query result: %w", err)
+		}
+		row := make([]string, len(values))
+		for i, v := range values {
+			row[i] = formatSQLValue(v)
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	return result, nil
+}
+
+// acquirePool returns the cached handle for the current version of conn,
+// opening a new one when the connection was updated since. A request holding
+// an older version of conn gets the newer pool rather than replacing it.
+// The caller must call release once done; a replaced pool is closed when
+// its last request releases it.
+func (s *sqlConnectionService) acquirePool(conn *types.SQLConnection) (*sqlPool, func(), error) {
+	s.poolMu.Lock()
+	defer s.poolMu.Unlock()
+	p := s.pools[conn.ID]
+	if p == nil || p.updatedAt.Before(conn.UpdatedAt) {
+		db, err := openSQLConnection(conn)
+		if err != nil {
+			return nil, nil, err
+		}
+		db.SetMaxOpenConns(4)
+		db.SetMaxIdleConns(2)
+		db.SetConnMaxIdleTime(5 * time.Minute)
+		if p != nil {
+			s.retirePool(p)
+		}
+		p = &sqlPool{db: db, updatedAt: conn.UpdatedAt}
+		if s.pools == nil {
+			s.pools = make(map[string]*sqlPool)
+		}
+		s.pools[conn.ID] = p
+	}
+	p.refs++
+	var once sync.Once
+	return p, func() { once.Do(func() { s.releasePool(p) }) }, nil
+}
+
+func (s *sqlConnectionService) releasePool(p *sqlPool) {
+	s.poolMu.Lock()
+	defer s.poolMu.Unlock()
+	p.refs--
+	if p.retired && p.refs == 0 {
+		p.db.Close()
+	}
+}
+
+// retirePool closes p now if no request uses it, otherwise when the last
+// one releases it. Callers must hold poolMu.
+func (s *sqlConnectionService) retirePool(p *sqlPool) {
+	p.retired = true
+	if p.refs == 0 {
+		p.db.Close()
+	}
+}
+
+func (s *sqlConnectionService) closePool(id string) {
+	s.poolMu.Lock()
+	defer s.poolMu.Unlock()
+	if p, ok := s.pools[id]; ok {
+		delete(s.pools, id)
+		s.retirePool(p)
+	}
+}
+
+// validateSQLConnection checks the required fields and that the host passes
+// the SSRF policy. Only the host is checked: database ports are on the SSRF
+// port blocklist, which is aimed at HTTP fetches rather than database dials.
+func validateSQLConnection(conn *types.SQLConnection) error {
+	if strings.TrimSpace(conn.Name) == "" {
+		return errors.NewBadRequestError("name is required")
+	}
+	if !conn.Driver.IsValid() {
+		return errors.NewBadRequestError(fmt.Sprintf("unsupported driver: %s", conn.Driver))
+	}
+	p := conn.Parameters
+	if p.Host == "" || p.Database == "" || p.Username == "" {
+		return errors.NewBadRequestError("host, database and username are required")
+	}
+	if p.Port < 0 || p.Port > 65535 {
+		return errors.NewBadRequestError("port must be between 1 and 65535")
+	}
+	if conn.MaxRows < 0 || conn.QueryTimeout < 0 {
+		return errors.NewBadRequestError("max_rows and query_timeout must not be negative")
+	}
+	if err := secutils.ValidateURLForSSRF(p.Host); err != nil {
+		return errors.NewValidationError(
+			secutils.FormatSSRFError("sql connection host", p.Host, err))
+	}
+	return nil
+}
+
+// sqlConnectionAddr is the host:port the driver dials.
+func sqlConnectionAddr(conn *types.SQLConnection) string {
+	port := conn.Parameters.Port
+	if port == 0 {
+		port = 5432
+		if conn.Driver == types.SQLDriverMySQL {
+			port = 3306
+		}
+	}
+	return net.JoinHostPort(conn.Parameters.Host, strconv.Itoa(port))
+}
+
+// openSQLConnection opens (but does not dial) a database handle for conn.
+func openSQLConnection(conn *types.SQLConnection) (*sql.DB, error) {
+	p := conn.Parameters
+	var (
+		driverName string
+		dsn        string
+	)
+	switch conn.Driver {
+	case types.SQLDriverPostgres:
+		sslMode := p.SSLMode
+		if sslMode == "" {
+			sslMode = "prefer"
+		}
+		u := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(p.Username, p.Password),
+			Host:     sqlConnectionAddr(conn),
+			Path:     "/" + p.Database,
+			RawQuery: url.Values{"sslmode": {sslMode}}.Encode(),
+		}
+		driverName, dsn = "pgx", u.String()
+	case types.SQLDriverMySQL:
+		// FormatDSN keeps special characters in credentials intact.
+		cfg := mysql.NewConfig()
+		cfg.User = p.Username
+		cfg.Passwd = p.Password
+		cfg.Net = "tcp"
+		cfg.Addr = sqlConnectionAddr(conn)
+		cfg.DBName = p.Database
+		cfg.Timeout = connectionTestTimeout
+		cfg.ParseTime = true
+		driverName, dsn = "mysql", cfg.FormatDSN()
+	default:
+		return nil, errors.NewBadRequestError(fmt.Sprintf("unsupported driver: %s", conn.Driver))
+	}
+	db, err := sql.Open(driverName, dsn)
+	if err != nil {
+		return nil, errors.NewBadRequestError(
+			fmt.Sprintf("failed to create %s connection: invalid configuration", conn.Driver))
+	}
+	return db, nil
+}
+
+// validateGeneratedSQL accepts a single SELECT that reads only the allowed
+// tables and no system catalogs or dangerous functions. Subqueries and CTEs
+// are also rejected when tables are restricted, so every table the query
+// reads is one the allowlist check has seen. The parser is PostgreSQL's, so MySQL
+// backtick quoting is mapped to double quotes before parsing.
+func validateGeneratedSQL(conn *types.SQLConnection, sqlText string) error {
+	parseText := sqlText
+	if conn.Driver == types.SQLDriverMySQL {
+		parseText = strings.ReplaceAll(parseText, "`", `"`)
+	}
+	opts := []secutils.SQLValidationOption{
+		secutils.WithInputValidation(6, maxGeneratedSQLLength),
+		secutils.WithSelectOnly(),
+		secutils.WithSingleStatement(),
+		secutils.WithNoSchemaAccess(),
+		secutils.WithNoDangerousFunctions(),
+	}
+	if len(conn.AllowedTables) > 0 {
+		opts = append(opts,
+			secutils.WithAllowedTables(conn.AllowedTables...),
+			secutils.WithNoSubqueries(),
+			secutils.WithNoCTEs(),
+		)
+	}
+	if _, validation := secutils.ValidateSQL(parseText, opts...); !validation.Valid {
+		msgs := make([]string, 0, len(validation.Errors))
+		for _, e := range validation.Errors {
+			msgs = append(msgs, e.Message)
+		}
+		return errors.NewBadRequestError("generated SQL rejected: " + strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+type schemaColumn struct {
+	table    string
+	column   string
+	dataType string
+}
+
+// formatSchema renders columns as one "table(column type, ...)" line per
+// table, keeping only allowed tables when any are set.
+func formatSchema(columns []schemaColumn, allowedTables []string) string {
+	allowed := make(map[string]bool, len(allowedTables))
+	for _, t := range allowedTables {
+		allowed[strings.ToLower(t)] = true
+	}
+	var (
+		b      strings.Builder
+		table  string
+		tables int
+	)
+	for _, c := range columns {
+		if len(allowed) > 0 && !allowed[strings.ToLower(c.table)] {
+			continue
+		}
+		if c.table != table {
+			if tables == maxSchemaTables {
+				break
+			}
+			if table != "" {
+				b.WriteString(")\n")
+			}
+			table = c.table
+			tables++
+			b.WriteString(c.table + "(")
+		} else {
+			b.WriteString(", ")
+		}
+		b.WriteString(c.column + " " + c.dataType)
+	}
+	if table != "" {
+		b.WriteString(")")
+	}
+	return b.String()
+}
+
+// formatSQLValue renders a scanned value for display.
+func formatSQLValue(v interface{}) string {
+	var s string
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if !utf8.Valid(x) {
+			return fmt.Sprintf("<%d bytes>", len(x))
+		}
+		s = string(x)
+	case time.Time:
+		if x.Hour() == 0 && x.Minute() == 0 && x.Second() == 0 && x.Nanosecond() == 0 {
+			return x.Format(time.DateOnly)
+		}
+		s = x.Format(time.RFC3339)
+	default:
+		s = fmt.Sprint(x)
+	}
+	if utf8.RuneCountInString(s) > maxSQLCellLength {
+		s = string([]rune(s)[:maxSQLCellLength]) + "…"
+	}
+	return s
+}
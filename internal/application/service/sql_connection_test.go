@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatSchema(t *testing.T) {
+	columns := []schemaColumn{
+		{"customers", "id", "integer"},
+		{"customers", "name", "text"},
+		{"orders", "id", "integer"},
+		{"orders", "amount", "numeric"},
+		{"secrets", "value", "text"},
+	}
+	assert.Equal(t,
+		"customers(id integer, name text)\norders(id integer, amount numeric)\nsecrets(value text)",
+		formatSchema(columns, nil))
+	assert.Equal(t, "orders(id integer, amount numeric)", formatSchema(columns, []string{"ORDERS"}))
+	assert.Equal(t, "", formatSchema(nil, nil))
+}
+
+func TestFormatSQLValue(t *testing.T) {
+	assert.Equal(t, "NULL", formatSQLValue(nil))
+	assert.Equal(t, "abc", formatSQLValue([]byte("abc")))
+	assert.Equal(t, "<2 bytes>", formatSQLValue([]byte{0xff, 0xfe}))
+	assert.Equal(t, "12.5", formatSQLValue(12.5))
+	assert.Equal(t, "2024-03-01", formatSQLValue(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "2024-03-01T08:30:00Z", formatSQLValue(time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)))
+	long := formatSQLValue(string(make([]rune, maxSQLCellLength+10)))
+	assert.Equal(t, maxSQLCellLength+1, len([]rune(long)))
+}
+
+func TestValidateGeneratedSQL(t *testing.T) {
+	pg := &types.SQLConnection{Driver: types.SQLDriverPostgres}
+	assert.NoError(t, validateGeneratedSQL(pg, "SELECT region, SUM(amount) FROM orders GROUP BY region"))
+	assert.Error(t, validateGeneratedSQL(pg, "DELETE FROM orders"))
+	assert.Error(t, validateGeneratedSQL(pg, "SELECT 1; DROP TABLE orders"))
+	assert.Error(t, validateGeneratedSQL(pg, "SELECT * FROM pg_catalog.pg_user"))
+
+	restricted := &types.SQLConnection{Driver: types.SQLDriverPostgres, AllowedTables: types.StringArray{"orders"}}
+	assert.NoError(t, validateGeneratedSQL(restricted, "SELECT COUNT(*) FROM orders"))
+	assert.Error(t, validateGeneratedSQL(restricted, "SELECT * FROM customers"))
+	assert.Error(t, validateGeneratedSQL(restricted, "SELECT * FROM orders WHERE id IN (SELECT id FROM customers)"))
+
+	my := &types.SQLConnection{Driver: types.SQLDriverMySQL}
+	assert.NoError(t, validateGeneratedSQL(my, "SELECT `region`, COUNT(*) FROM `orders` GROUP BY `region`"))
+}
+
+func TestValidateSQLConnection(t *testing.T) {
+	withSSRFWhitelist(t, "db.allowed.test")
+	valid := func() *types.SQLConnection {
+		return &types.SQLConnection{
+			Name:   "sales",
+			Driver: types.SQLDriverPostgres,
+			Parameters: types.SQLConnectionParameters{
+				Host: "db.allowed.test", Database: "sales", Username: "reader",
+			},
+		}
+	}
+	assert.NoError(t, validateSQLConnection(valid()))
+
+	c := valid()
+	c.Driver = "oracle"
+	assert.Error(t, validateSQLConnection(c))
+
+	c = valid()
+	c.Parameters.Database = ""
+	assert.Error(t, validateSQLConnection(c))
+
+	c = valid()
+	c.Parameters.Host = "169.254.169.254"
+	assert.Error(t, validateSQLConnection(c))
+}
+
+func TestSQLConnectionPoolConcurrentUse(t *testing.T) {
+	s := &sqlConnectionService{}
+	updatedAt := time.Now()
+	conn := func(updatedAt time.Time) *types.SQLConnection {
+		return &types.SQLConnection{
+			ID:     "conn-1",
+			Driver: types.SQLDriverPostgres,
+			Parameters: types.SQLConnectionParameters{
+				Host: "127.0.0.1", Port: 1, Database: "sales", Username: "reader",
+			},
+			UpdatedAt: updatedAt,
+		}
+	}
+	isClosed := func(p *sqlPool) bool {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := p.db.PingContext(ctx)
+		return err != nil && err.Error() == "sql: database is closed"
+	}
+
+	const workers = 16
+	pools := make([]*sqlPool, workers)
+	releases := make([]func(), workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, release, err := s.acquirePool(conn(updatedAt))
+			assert.NoError(t, err)
+			pools[i], releases[i] = p, release
+		}()
+	}
+	wg.Wait()
+	first := pools[0]
+	require.NotNil(t, first)
+	for _, p := range pools {
+		assert.Same(t, first, p, "concurrent requests share one pool")
+	}
+	assert.False(t, isClosed(first))
+
+	newer, releaseNewer, err := s.acquirePool(conn(updatedAt.Add(time.Second)))
+	require.NoError(t, err)
+	assert.NotSame(t, first, newer)
+	assert.False(t, isClosed(first), "a replaced pool stays open while requests use it")
+	for _, release := range releases {
+		release()
+	}
+	assert.True(t, isClosed(first), "a replaced pool closes once its last request is done")
+
+	stale, releaseStale, err := s.acquirePool(conn(updatedAt))
+	require.NoError(t, err)
+	assert.Same(t, newer, stale, "a request with an older version does not replace the pool")
+	releaseStale()
+	releaseStale()
+	assert.False(t, isClosed(newer), "a release is counted once")
+
+	s.closePool("conn-1")
+	assert.False(t, isClosed(newer))
+	releaseNewer()
+	assert.True(t, isClosed(newer))
+}
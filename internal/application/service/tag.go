@@ -146,6 +146,7 @@ func (s *knowledgeTagService) CreateTag(
 	name string,
 	color string,
 	sortOrder int,
+	parentID string,
 ) (*types.KnowledgeTag, error) {
 	name = strings.TrimSpace(name)
 	if kbID == "" || name == "" {
@@ -164,6 +165,10 @@ func (s *knowledgeTagService) CreateTag(
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
+	parentID = strings.TrimSpace(parentID)
+	if err := s.validateTagParent(ctx, kb.TenantID, kb.ID, "", parentID); err != nil {
+		return nil, err
+	}
 
 	now := time.Now()
 	// "未分类" tag should have the lowest sort order to appear first
@@ -174,6 +179,7 @@ func (s *knowledgeTagService) CreateTag(
 		ID:              uuid.New().String(),
 		TenantID:        kb.TenantID,
 		KnowledgeBaseID: kb.ID,
+		ParentID:        parentID,
 		Name:            name,
 		Color:           strings.TrimSpace(color),
 		SortOrder:       sortOrder,
@@ -183,6 +189,9 @@ func (s *knowledgeTagService) CreateTag(
 	if err := s.repo.Create(ctx, tag); err != nil {
 		return nil, err
 	}
+	if name != types.UntaggedTagName {
+		ScheduleAutoRetag(ctx, s.task, kb)
+	}
 	return tag, nil
 }
 
@@ -193,6 +202,7 @@ func (s *knowledgeTagService) UpdateTag(
 	name *string,
 	color *string,
 	sortOrder *int,
+	parentID *string,
 ) (*types.KnowledgeTag, error) {
 	if id == "" {
 		return nil, werrors.NewBadRequestError("标签ID不能为空")
@@ -203,11 +213,13 @@ func (s *knowledgeTagService) UpdateTag(
 		return nil, err
 	}
 
+	renamed := false
 	if name != nil {
 		newName := strings.TrimSpace(*name)
 		if newName == "" {
 			return nil, werrors.NewBadRequestError("标签名称不能为空")
 		}
+		renamed = newName != tag.Name
 		tag.Name = newName
 	}
 	if color != nil {
@@ -216,10 +228,22 @@ func (s *knowledgeTagService) UpdateTag(
 	if sortOrder != nil {
 		tag.SortOrder = *sortOrder
 	}
+	if parentID != nil {
+		newParentID := strings.TrimSpace(*parentID)
+		if err := s.validateTagParent(ctx, tenantID, tag.KnowledgeBaseID, tag.ID, newParentID); err != nil {
+			return nil, err
+		}
+		tag.ParentID = newParentID
+	}
 	tag.UpdatedAt = time.Now()
 	if err := s.repo.Update(ctx, tag); err != nil {
 		return nil, err
 	}
+	if renamed {
+		if kb, err := s.kbService.GetKnowledgeBaseByID(ctx, tag.KnowledgeBaseID); err == nil {
+			ScheduleAutoRetag(ctx, s.task, kb)
+		}
+	}
 	return tag, nil
 }
 
@@ -342,7 +366,51 @@ func (s *knowledgeTagService) DeleteTag(ctx context.Context, id string, force bo
 	if len(excludeIDs) > 0 {
 		return nil
 	}
-	return s.repo.Delete(ctx, tenantID, id)
+	// Child tags move up to the deleted tag's parent rather than becoming orphans.
+	if err := s.repo.ReparentChildren(ctx, tenantID, tag.KnowledgeBaseID, tag.ID, tag.ParentID); err != nil {
+		return err
+	}
+	if err := s.repo.Delete(ctx, tenantID, id); err != nil {
+		return err
+	}
+	ScheduleAutoRetag(ctx, s.task, kb)
+	return nil
+}
+
+// validateTagParent checks that parentID is a tag of the same knowledge base
+// and, when moving the existing tag tagID, that it is not tagID itself or one
+// of its descendants. An empty parentID is a top-level tag.
+func (s *knowledgeTagService) validateTagParent(
+	ctx context.Context,
+	tenantID uint64,
+	kbID string,
+	tagID string,
+	parentID string,
+) error {
+	if parentID == "" {
+		return nil
+	}
+	parent, err := s.repo.GetByID(ctx, tenantID, parentID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return werrors.NewBadRequestError("父标签不存在")
+		}
+		return err
+	}
+	if parent.KnowledgeBaseID != kbID {
+		return werrors.NewBadRequestError("父标签不属于该知识库")
+	}
+	if tagID == "" {
+		return nil
+	}
+	tags, err := s.repo.ListAllByKB(ctx, tenantID, kbID)
+	if err != nil {
+		return err
+	}
+	if types.IsTagInSubtree(tags, parentID, tagID) {
+		return werrors.NewBadRequestError("不能将标签移动到其自身或其子标签下")
+	}
+	return nil
 }
 
 // enqueueIndexDeleteTask enqueues an async task for index deletion (low priority).
@@ -467,5 +535,5 @@ func (s *knowledgeTagService) FindOrCreateTagByName(ctx context.Context, kbID st
 	}
 
 	// 创建新标签
-	return s.CreateTag(ctx, kbID, name, "", 0)
+	return s.CreateTag(ctx, kbID, name, "", 0, "")
 }
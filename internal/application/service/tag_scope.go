@@ -0,0 +1,280 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	werrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// tagAccessSubjectPriority orders matching rules: the most specific subject
+// decides what a caller may search.
+var tagAccessSubjectPriority = map[string]int{
+	types.TagAccessSubjectUser:   0,
+	types.TagAccessSubjectAPIKey: 1,
+	types.TagAccessSubjectRole:   2,
+}
+
+// tagScopeService implements TagScopeService.
+type tagScopeService struct {
+	kbRepo        interfaces.KnowledgeBaseRepository
+	knowledgeRepo interfaces.KnowledgeRepository
+	tagRepo       interfaces.KnowledgeTagRepository
+	ruleRepo      interfaces.TagAccessRuleRepository
+}
+
+// NewTagScopeService creates a new tag scope service.
+func NewTagScopeService(
+	kbRepo interfaces.KnowledgeBaseRepository,
+	knowledgeRepo interfaces.KnowledgeRepository,
+	tagRepo interfaces.KnowledgeTagRepository,
+	ruleRepo interfaces.TagAccessRuleRepository,
+) interfaces.TagScopeService {
+	return &tagScopeService{
+		kbRepo:        kbRepo,
+		knowledgeRepo: knowledgeRepo,
+		tagRepo:       tagRepo,
+		ruleRepo:      ruleRepo,
+	}
+}
+
+// ScopeSearchTargets expands tag filters to descendant tags and applies the
+// caller's tag access rules. Tag filters of FAQ knowledge bases stay tag IDs,
+// which the FAQ index stores per entry; those of document knowledge bases are
+// resolved to the documents carrying the tags. Requests made with an API key
+// restricted to some knowledge bases lose the targets outside them.
+func (s *tagScopeService) ScopeSearchTargets(
+	ctx context.Context,
+	targets types.SearchTargets,
+) (types.SearchTargets, error) {
+	if key, ok := types.APIKeyFromContext(ctx); ok {
+		targets = restrictTargetsToAPIKey(ctx, key, targets)
+	}
+	if len(targets) == 0 {
+		return targets, nil
+	}
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	kbIDs := targets.GetAllKnowledgeBaseIDs()
+	kbs, err := s.kbRepo.GetKnowledgeBaseByIDs(ctx, kbIDs)
+	if err != nil {
+		return nil, err
+	}
+	kbByID := make(map[string]*types.KnowledgeBase, len(kbs))
+	for _, kb := range kbs {
+		if kb != nil {
+			kbByID[kb.ID] = kb
+		}
+	}
+	rules, err := s.ruleRepo.ListForSubjects(ctx, tenantID, kbIDs, types.TagAccessSubjectsFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	ruleByKB := make(map[string]*types.TagAccessRule, len(rules))
+	for _, rule := range rules {
+		current := ruleByKB[rule.KnowledgeBaseID]
+		if current == nil || tagAccessSubjectPriority[rule.SubjectType] < tagAccessSubjectPriority[current.SubjectType] {
+			ruleByKB[rule.KnowledgeBaseID] = rule
+		}
+	}
+
+	tagsByKB := make(map[string][]*types.KnowledgeTag)
+	kbTags := func(kb *types.KnowledgeBase) ([]*types.KnowledgeTag, error) {
+		if tags, ok := tagsByKB[kb.ID]; ok {
+			return tags, nil
+		}
+		tags, err := s.tagRepo.ListAllByKB(ctx, kb.TenantID, kb.ID)
+		if err != nil {
+			return nil, err
+		}
+		tagsByKB[kb.ID] = tags
+		return tags, nil
+	}
+
+	scoped := make(types.SearchTargets, 0, len(targets))
+	for _, target := range targets {
+		kb := kbByID[target.KnowledgeBaseID]
+		// Rules only bind callers of the tenant owning the knowledge base;
+		// shared knowledge bases are governed by their share permission.
+		rule := ruleByKB[target.KnowledgeBaseID]
+		if kb == nil || kb.TenantID != tenantID {
+			rule = nil
+		}
+		if kb == nil || (len(target.TagIDs) == 0 && rule == nil) {
+			scoped = append(scoped, target)
+			continue
+		}
+		tags, err := kbTags(kb)
+		if err != nil {
+			return nil, err
+		}
+
+		var tagIDs []string
+		if len(target.TagIDs) > 0 {
+			tagIDs = types.ExpandTagDescendants(tags, target.TagIDs)
+		}
+		if rule != nil {
+			allowed := types.ExpandTagDescendants(tags, rule.TagIDs)
+			if tagIDs == nil {
+				tagIDs = allowed
+			} else {
+				tagIDs = intersectPreservingRequestOrder(tagIDs, allowed)
+			}
+		}
+		if len(tagIDs) == 0 {
+			logger.Infof(ctx, "Tag scope leaves nothing to search in KB %s, dropping target", kb.ID)
+			continue
+		}
+
+		narrowed := *target
+		if kb.Type == types.KnowledgeBaseTypeFAQ {
+			narrowed.TagIDs = tagIDs
+		} else {
+			knowledgeIDs, err := s.knowledgeRepo.ListIDsByTagIDs(ctx, kb.TenantID, kb.ID, tagIDs)
+			if err != nil {
+				return nil, err
+			}
+			if target.Type == types.SearchTargetTypeKnowledge {
+				knowledgeIDs = intersectPreservingRequestOrder(target.KnowledgeIDs, knowledgeIDs)
+			}
+			if len(knowledgeIDs) == 0 {
+				logger.Infof(ctx, "No documents carry the scoped tags in KB %s, dropping target", kb.ID)
+				continue
+			}
+			narrowed.KnowledgeIDs = knowledgeIDs
+			narrowed.TagIDs = nil
+		}
+		scoped = append(scoped, &narrowed)
+	}
+	return scoped, nil
+}
+
+// ownKnowledgeBase loads a knowledge base of the caller's tenant.
+func (s *tagScopeService) ownKnowledgeBase(ctx context.Context, kbID string) (*types.KnowledgeBase, error) {
+	tenantID := types.MustTenantIDFromContext(ctx)
+	kb, err := s.kbRepo.GetKnowledgeBaseByID(ctx, kbID)
+	if err != nil || kb == nil || kb.TenantID != tenantID {
+		return nil, werrors.NewNotFoundError("知识库不存在")
+	}
+	return kb, nil
+}
+
+// ListAccessRules lists the tag access rules of a knowledge base.
+func (s *tagScopeService) ListAccessRules(ctx context.Context, kbID string) ([]*types.TagAccessRule, error) {
+	kb, err := s.ownKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	return s.ruleRepo.ListByKB(ctx, kb.TenantID, kb.ID)
+}
+
+// SaveAccessRule creates or replaces the rule of a subject on a knowledge base.
+func (s *tagScopeService) SaveAccessRule(
+	ctx context.Context,
+	kbID string,
+	req *types.TagAccessRuleRequest,
+) (*types.TagAccessRule, error) {
+	kb, err := s.ownKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return nil, err
+	}
+	subjectID := strings.TrimSpace(req.SubjectID)
+	switch req.SubjectType {
+	case types.TagAccessSubjectUser:
+		if subjectID == "" {
+			return nil, werrors.NewBadRequestError("用户规则必须指定 subject_id")
+		}
+	case types.TagAccessSubjectRole:
+		if !types.TenantRole(subjectID).IsValid() {
+			return nil, werrors.NewBadRequestError("角色规则的 subject_id 必须是有效的租户角色")
+		}
+	case types.TagAccessSubjectAPIKey:
+		subjectID = ""
+	default:
+		return nil, werrors.NewBadRequestError("subject_type 必须是 user、role 或 api_key")
+	}
+
+	tagIDs := dedupPreservingOrder(req.TagIDs)
+	if len(tagIDs) == 0 {
+		return nil, werrors.NewBadRequestError("至少需要指定一个标签")
+	}
+	tags, err := s.tagRepo.GetByIDs(ctx, kb.TenantID, tagIDs)
+	if err != nil {
+		return nil, err
+	}
+	found := 0
+	for _, tag := range tags {
+		if tag.KnowledgeBaseID == kb.ID {
+			found++
+		}
+	}
+	if found != len(tagIDs) {
+		return nil, werrors.NewBadRequestError("标签不存在或不属于该知识库")
+	}
+
+	now := time.Now()
+	rule := &types.TagAccessRule{
+		ID:              uuid.New().String(),
+		TenantID:        kb.TenantID,
+		KnowledgeBaseID: kb.ID,
+		SubjectType:     req.SubjectType,
+		SubjectID:       subjectID,
+		TagIDs:          tagIDs,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	existing, err := s.ruleRepo.ListByKB(ctx, kb.TenantID, kb.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range existing {
+		if r.SubjectType == rule.SubjectType && r.SubjectID == rule.SubjectID {
+			rule.ID = r.ID
+			rule.CreatedAt = r.CreatedAt
+		}
+	}
+	if err := s.ruleRepo.Save(ctx, rule); err != nil {
+		return nil, err
+	}
+	logger.Infof(ctx, "Saved tag access rule %s for %s %q on KB %s",
+		rule.ID, rule.SubjectType, rule.SubjectID, kb.ID)
+	return rule, nil
+}
+
+// DeleteAccessRule deletes a tag access rule of a knowledge base.
+func (s *tagScopeService) DeleteAccessRule(ctx context.Context, kbID string, ruleID string) error {
+	kb, err := s.ownKnowledgeBase(ctx, kbID)
+	if err != nil {
+		return err
+	}
+	if err := s.ruleRepo.Delete(ctx, kb.TenantID, kb.ID, ruleID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return werrors.NewNotFoundError("标签访问规则不存在")
+		}
+		return err
+	}
+	return nil
+}
+
+// restrictTargetsToAPIKey drops the targets whose knowledge base the key may
+// not reach.
+func restrictTargetsToAPIKey(ctx context.Context, key *types.APIKey, targets types.SearchTargets) types.SearchTargets {
+	if len(key.KnowledgeBaseIDs) == 0 {
+		return targets
+	}
+	allowed := make(types.SearchTargets, 0, len(targets))
+	for _, target := range targets {
+		if key.AllowsKnowledgeBase(target.KnowledgeBaseID) {
+			allowed = append(allowed, target)
+			continue
+		}
+		logger.Infof(ctx, "API key %s is not allowed to search KB %s, dropping target", key.ID, target.KnowledgeBaseID)
+	}
+	return allowed
+}
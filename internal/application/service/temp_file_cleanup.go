@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	filesvc "github.com/Tencent/WeKnora/internal/application/service/file"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// TempFileCleanupRunner deletes expired temp files (exports and other
+// SaveBytes(temp=true) output) once an hour. Buckets the file services
+// create carry an expiration rule for temp files, but pre-existing buckets
+// and local storage have none, and a tenant's temp_retention_hours may be
+// shorter than the bucket-wide rule — this sweep covers both.
+//
+// Each tenant is swept in the global file service and in every provider of
+// its own storage config, since temp files land in whichever storage the
+// knowledge base resolved to.
+type TempFileCleanupRunner struct {
+	tenantRepo interfaces.TenantRepository
+	fileSvc    interfaces.FileService
+	interval   time.Duration
+	now        func() time.Time
+	// newFileService builds a tenant's provider-specific file service;
+	// swapped out in tests.
+	newFileService func(provider string, sec *types.StorageEngineConfig) (interfaces.FileService, error)
+
+	startOnce sync.Once
+	stopOnce  sync.Once
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+	// started lets Stop tell "never started" apart from "running"; see
+	// AuditLogRetentionRunner.
+	started atomic.Bool
+}
+
+const (
+	// tempFileCleanupInterval is the gap between sweeps. Retention is
+	// configured in hours, so an hourly sweep keeps files at most an hour
+	// past their retention.
+	tempFileCleanupInterval = time.Hour
+	// tempFileCleanupStartupDelay holds the first sweep until after boot.
+	tempFileCleanupStartupDelay = 10 * time.Minute
+	// tempFileCleanupTimeout bounds a single sweep across all tenants.
+	tempFileCleanupTimeout = 10 * time.Minute
+)
+
+// NewTempFileCleanupRunner constructs the runner. Nothing fires until
+// Start is called.
+func NewTempFileCleanupRunner(
+	tenantRepo interfaces.TenantRepository, fileSvc interfaces.FileService,
+) *TempFileCleanupRunner {
+	return &TempFileCleanupRunner{
+		tenantRepo: tenantRepo,
+		fileSvc:    fileSvc,
+		interval:   tempFileCleanupInterval,
+		now:        time.Now,
+		newFileService: func(provider string, sec *types.StorageEngineConfig) (interfaces.FileService, error) {
+			svc, _, err := filesvc.NewFileServiceFromStorageConfig(provider, sec, "")
+			return svc, err
+		},
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+}
+
+// Start spins up the background goroutine. Calling it more than once is a
+// no-op.
+func (r *TempFileCleanupRunner) Start(ctx context.Context) {
+	if r == nil || r.tenantRepo == nil {
+		return
+	}
+	r.startOnce.Do(func() {
+		r.started.Store(true)
+		logger.Infof(ctx, "[temp-cleanup] starting sweep: default_retention=%s interval=%s",
+			filesvc.TempFileRetention(), r.interval)
+		go r.loop()
+	})
+}
+
+// Stop signals the loop to exit and blocks until it returns. Idempotent.
+func (r *TempFileCleanupRunner) Stop() {
+	if r == nil || !r.started.Load() {
+		return
+	}
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+	})
+	<-r.doneCh
+}
+
+func (r *TempFileCleanupRunner) loop() {
+	defer close(r.doneCh)
+
+	startupTimer := time.NewTimer(tempFileCleanupStartupDelay)
+	defer startupTimer.Stop()
+	select {
+	case <-startupTimer.C:
+	case <-r.stopCh:
+		return
+	}
+
+	r.runOnce()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.runOnce()
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+// runOnce sweeps every tenant. Failures are logged at WARN and retried on
+// the next sweep; a leftover temp file only costs storage.
+func (r *TempFileCleanupRunner) runOnce() {
+	ctx, cancel := context.WithTimeout(context.Background(), tempFileCleanupTimeout)
+	defer cancel()
+
+	tenants, err := r.tenantRepo.ListTenants(ctx)
+	if err != nil {
+		logger.Warnf(ctx, "[temp-cleanup] failed to list tenants: %v", err)
+		return
+	}
+	total := 0
+	for _, tenant := range tenants {
+		total += r.cleanupTenant(ctx, tenant)
+	}
+	if total > 0 {
+		logger.Infof(ctx, "[temp-cleanup] sweep complete: deleted=%d tenants=%d", total, len(tenants))
+	} else {
+		logger.Debugf(ctx, "[temp-cleanup] sweep complete: deleted=0 tenants=%d", len(tenants))
+	}
+}
+
+// cleanupTenant deletes the tenant's expired temp files from every storage
+// it may have written to and returns how many were deleted.
+func (r *TempFileCleanupRunner) cleanupTenant(ctx context.Context, tenant *types.Tenant) int {
+	sec := tenant.StorageEngineConfig
+	retention := filesvc.TempFileRetention()
+	if sec != nil && sec.TempRetentionHours > 0 {
+		retention = time.Duration(sec.TempRetentionHours) * time.Hour
+	}
+	cutoff := r.now().Add(-retention)
+
+	services := []interfaces.FileService{r.fileSvc}
+	providers := sec.ConfiguredProviders()
+	if sec != nil {
+		if p := strings.ToLower(strings.TrimSpace(sec.DefaultProvider)); p != "" && !slices.Contains(providers, p) {
+			providers = append(providers, p)
+		}
+	}
+	for _, provider := range providers {
+		svc, err := r.newFileService(provider, sec)
+		if err != nil {
+			logger.Warnf(ctx, "[temp-cleanup] tenant %d: cannot open %s storage: %v", tenant.ID, provider, err)
+			continue
+		}
+		services = append(services, svc)
+	}
+
+	deleted := 0
+	for _, svc := range services {
+		cleaner, ok := filesvc.As[interfaces.TempFileCleaner](svc)
+		if !ok {
+			continue
+		}
+		n, err := cleaner.CleanupTempFiles(ctx, tenant.ID, cutoff)
+		deleted += n
+		if err != nil {
+			logger.Warnf(ctx, "[temp-cleanup] tenant %d: %v", tenant.ID, err)
+		}
+	}
+	return deleted
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	filesvc "github.com/Tencent/WeKnora/internal/application/service/file"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// stubTenantRepoForTempCleanup serves a fixed tenant list. Unstubbed
+// methods nil-panic through the embedded interface.
+type stubTenantRepoForTempCleanup struct {
+	interfaces.TenantRepository
+	tenants []*types.Tenant
+}
+
+func (s *stubTenantRepoForTempCleanup) ListTenants(context.Context) ([]*types.Tenant, error) {
+	return s.tenants, nil
+}
+
+func saveAgedTempFile(t *testing.T, svc interfaces.FileService, baseDir string, tenantID uint64, age time.Duration) string {
+	t.Helper()
+	path, err := svc.SaveBytes(context.Background(), []byte("export"), tenantID, "export.csv", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := filepath.Join(baseDir, filepath.FromSlash(path[len("local://"):]))
+	mtime := time.Now().Add(-age)
+	if err := os.Chtimes(full, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+	return full
+}
+
+func TestTempFileCleanup_HonorsTenantRetention(t *testing.T) {
+	t.Setenv("TEMP_FILE_RETENTION_HOURS", "24")
+	baseDir := t.TempDir()
+	global := filesvc.NewLocalFileService(baseDir, "")
+
+	// Tenant 1 uses the 24h default, tenant 2 keeps temp files for 2h.
+	keep := saveAgedTempFile(t, global, baseDir, 1, 3*time.Hour)
+	expired := saveAgedTempFile(t, global, baseDir, 1, 25*time.Hour)
+	shortExpired := saveAgedTempFile(t, global, baseDir, 2, 3*time.Hour)
+	permanent, err := global.SaveBytes(context.Background(), []byte("kept"), 1, "report.csv", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runner := NewTempFileCleanupRunner(&stubTenantRepoForTempCleanup{tenants: []*types.Tenant{
+		{ID: 1},
+		{ID: 2, StorageEngineConfig: &types.StorageEngineConfig{TempRetentionHours: 2}},
+	}}, global)
+	runner.runOnce()
+
+	for path, wantExists := range map[string]bool{keep: true, expired: false, shortExpired: false} {
+		if _, err := os.Stat(path); (err == nil) != wantExists {
+			t.Errorf("%s: exists=%v, want %v", path, err == nil, wantExists)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(baseDir, filepath.FromSlash(permanent[len("local://"):]))); err != nil {
+		t.Errorf("non-temp file was removed: %v", err)
+	}
+}
+
+func TestTempFileCleanup_StopWithoutStart(t *testing.T) {
+	runner := NewTempFileCleanupRunner(&stubTenantRepoForTempCleanup{}, nil)
+	done := make(chan struct{})
+	go func() {
+		runner.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Stop blocked on a runner that was never started")
+	}
+}
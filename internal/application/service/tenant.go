@@ -7,9 +7,11 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
 	"io"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -34,12 +36,13 @@ type ListTenantsParams struct {
 
 // tenantService implements the TenantService interface
 type tenantService struct {
-	repo interfaces.TenantRepository // Repository for tenant data operations
+	repo  interfaces.TenantRepository // Repository for tenant data operations
+	audit interfaces.AuditLogService  // optional; nil ⇒ no audit, business ops still succeed
 }
 
 // NewTenantService creates a new tenant service instance
-func NewTenantService(repo interfaces.TenantRepository) interfaces.TenantService {
-	return &tenantService{repo: repo}
+func NewTenantService(repo interfaces.TenantRepository, audit interfaces.AuditLogService) interfaces.TenantService {
+	return &tenantService{repo: repo, audit: audit}
 }
 
 // CreateTenant creates a new tenant
@@ -59,6 +62,9 @@ func (s *tenantService) CreateTenant(ctx context.Context, tenant *types.Tenant)
 	tenant.CreatedAt = time.Now()
 	tenant.UpdatedAt = time.Now()
 
+	if err := validateStorageEngineSettings(tenant.StorageEngineConfig); err != nil {
+		return nil, err
+	}
 	if err := s.validateStorageBucketUniqueness(ctx, tenant); err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"tenant_name": tenant.Name,
@@ -144,6 +150,9 @@ func (s *tenantService) UpdateTenant(ctx context.Context, tenant *types.Tenant)
 
 	logger.Infof(ctx, "Updating tenant, ID: %d, name: %s", tenant.ID, tenant.Name)
 
+	if err := validateStorageEngineSettings(tenant.StorageEngineConfig); err != nil {
+		return nil, err
+	}
 	if err := s.validateStorageBucketUniqueness(ctx, tenant); err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"tenant_id": tenant.ID,
@@ -248,6 +257,55 @@ func (s *tenantService) UpdateAPIKey(ctx context.Context, id uint64) (string, er
 	return plaintextAPIKey, nil
 }
 
+// UpdateAPIKeyRole sets the tenant role granted to requests authenticated
+// with the tenant's API key. The owner role cannot be granted.
+func (s *tenantService) UpdateAPIKeyRole(ctx context.Context, id uint64, role types.TenantRole) (*types.Tenant, error) {
+	if id == 0 {
+		logger.Error(ctx, "Tenant ID cannot be 0")
+		return nil, errors.New("tenant ID cannot be 0")
+	}
+	if !role.AssignableToAPIKey() {
+		return nil, werrors.NewBadRequestError("API Key 角色只能是 viewer、contributor 或 admin")
+	}
+
+	tenant, err := s.repo.GetTenantByID(ctx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"tenant_id": id,
+		})
+		return nil, err
+	}
+
+	oldRole := tenant.EffectiveAPIKeyRole()
+	tenant.APIKeyRole = role
+	if err := s.repo.UpdateTenant(ctx, tenant); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"tenant_id": id,
+		})
+		return nil, err
+	}
+
+	if s.audit != nil && oldRole != role {
+		details, _ := json.Marshal(map[string]string{
+			"old_role": string(oldRole),
+			"new_role": string(role),
+		})
+		_ = s.audit.Log(ctx, &types.AuditLog{
+			TenantID:    id,
+			ActorUserID: auditActor(ctx),
+			ActorRole:   auditActorRole(ctx),
+			Action:      types.AuditActionAPIKeyRoleChanged,
+			TargetType:  "tenant",
+			TargetID:    strconv.FormatUint(id, 10),
+			Outcome:     types.AuditOutcomeSuccess,
+			Details:     types.JSON(details),
+		})
+	}
+
+	logger.Infof(ctx, "Tenant API Key role updated, ID: %d, role: %s -> %s", id, oldRole, role)
+	return tenant, nil
+}
+
 // generateApiKey generates a secure API key for tenant authentication
 func (r *tenantService) generateApiKey(tenantID uint64) string {
 	// 1. Convert tenant_id to bytes
@@ -407,6 +465,27 @@ func (s *tenantService) GetWeKnoraCloudCredentials(ctx context.Context) *types.W
 	return tenant.Credentials.GetWeKnoraCloud()
 }
 
+// validateStorageEngineSettings checks the provider-independent parts of a
+// tenant's storage config.
+func validateStorageEngineSettings(sec *types.StorageEngineConfig) error {
+	if sec == nil {
+		return nil
+	}
+	if err := sec.Encryption.Validate(); err != nil {
+		return werrors.NewBadRequestError(err.Error())
+	}
+	if sec.TempRetentionHours < 0 {
+		return werrors.NewBadRequestError("temp_retention_hours must not be negative")
+	}
+	if err := sec.UploadScan.Validate(); err != nil {
+		return werrors.NewBadRequestError(err.Error())
+	}
+	if err := sec.FileURL.Validate(); err != nil {
+		return werrors.NewBadRequestError(err.Error())
+	}
+	return nil
+}
+
 func (s *tenantService) validateStorageBucketUniqueness(ctx context.Context, tenant *types.Tenant) error {
 	if tenant.StorageEngineConfig == nil {
 		return nil
@@ -449,6 +528,9 @@ func (s *tenantService) validateStorageBucketUniqueness(ctx context.Context, ten
 		if cfg.OSS != nil && cfg.OSS.BucketName != "" {
 			res["oss"] = cfg.OSS.BucketName
 		}
+		if cfg.GCS != nil && cfg.GCS.BucketName != "" {
+			res["gcs"] = cfg.GCS.BucketName
+		}
 		return res
 	}
 
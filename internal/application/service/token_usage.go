@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+const (
+	// tokenBudgetCacheTTL is how long the budget and used tokens of a tenant
+	// are reused between budget checks. Usage recorded by this process is
+	// added to the cached counts right away.
+	tokenBudgetCacheTTL = 30 * time.Second
+	// defaultTokenUsageReportDays is the range of a report without dates.
+	defaultTokenUsageReportDays = 30
+	// maxTokenUsageReportDays caps the range of a report.
+	maxTokenUsageReportDays = 366
+)
+
+// tenantTokenUsage caches the budget of a tenant and its used tokens of day.
+type tenantTokenUsage struct {
+	day      string
+	budget   *types.TokenBudget
+	daily    int64
+	monthly  int64
+	loadedAt time.Time
+}
+
+// tokenUsageService implements interfaces.TokenUsageService
+type tokenUsageService struct {
+	repo       interfaces.TokenUsageRepository
+	tenantRepo interfaces.TenantRepository
+	now        func() time.Time
+
+	mu    sync.Mutex
+	cache map[uint64]*tenantTokenUsage
+}
+
+// NewTokenUsageService creates a new token usage service
+func NewTokenUsageService(
+	repo interfaces.TokenUsageRepository, tenantRepo interfaces.TenantRepository,
+) interfaces.TokenUsageService {
+	return &tokenUsageService{
+		repo:       repo,
+		tenantRepo: tenantRepo,
+		now:        time.Now,
+		cache:      make(map[uint64]*tenantTokenUsage),
+	}
+}
+
+// RecordUsage adds a model call to the daily aggregate of the tenant and
+// session of ctx. Calls without a tenant (e.g. model connection tests
+// before login) are not accounted.
+func (s *tokenUsageService) RecordUsage(ctx context.Context, event *types.TokenUsageEvent) {
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	if tenantID == 0 || event == nil {
+		return
+	}
+	sessionID, _ := types.SessionIDFromContext(ctx)
+	day := s.now().UTC().Format(types.TokenUsageDayLayout)
+	row := &types.TokenUsageDaily{
+		TenantID:         tenantID,
+		Day:              day,
+		ModelID:          event.ModelID,
+		SessionID:        sessionID,
+		ModelName:        event.ModelName,
+		Requests:         1,
+		PromptTokens:     event.PromptTokens,
+		CompletionTokens: event.CompletionTokens,
+		EmbeddingTokens:  event.EmbeddingTokens,
+	}
+	if event.Estimated {
+		row.EstimatedRequests = 1
+	}
+	// The call has already been paid for; record it even if the request
+	// that made it is gone.
+	if err := s.repo.AddUsage(context.WithoutCancel(ctx), row); err != nil {
+		logger.Warnf(ctx, "Failed to record token usage of model %s: %v", event.ModelID, err)
+	}
+
+	tokens := event.PromptTokens + event.CompletionTokens + event.EmbeddingTokens
+	s.mu.Lock()
+	if cached, ok := s.cache[tenantID]; ok && cached.day == day {
+		cached.daily += tokens
+		cached.monthly += tokens
+	}
+	s.mu.Unlock()
+}
+
+// CheckBudget rejects model calls of a tenant that has used up its daily or
+// monthly token budget. Lookup failures let the call through: accounting
+// must not take the models down.
+func (s *tokenUsageService) CheckBudget(ctx context.Context) error {
+	tenantID, _ := types.TenantIDFromContext(ctx)
+	if tenantID == 0 {
+		return nil
+	}
+	usage, err := s.cachedUsage(ctx, tenantID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to check the token budget of tenant %d: %v", tenantID, err)
+		return nil
+	}
+	if usage.budget.Exceeds(usage.daily, usage.monthly) {
+		return fmt.Errorf("%w: tenant %d used %d tokens today and %d this month",
+			types.ErrTokenBudgetExceeded, tenantID, usage.daily, usage.monthly)
+	}
+	return nil
+}
+
+// cachedUsage returns the budget and used tokens of a tenant, loading them
+// when the cached ones are stale.
+func (s *tokenUsageService) cachedUsage(ctx context.Context, tenantID uint64) (tenantTokenUsage, error) {
+	now := s.now()
+	day := now.UTC().Format(types.TokenUsageDayLayout)
+	s.mu.Lock()
+	cached, ok := s.cache[tenantID]
+	if ok && cached.day == day && now.Sub(cached.loadedAt) < tokenBudgetCacheTTL {
+		usage := *cached
+		s.mu.Unlock()
+		return usage, nil
+	}
+	s.mu.Unlock()
+
+	usage := tenantTokenUsage{day: day, loadedAt: now}
+	tenant, ok := types.TenantInfoFromContext(ctx)
+	if !ok || tenant.ID != tenantID {
+		var err error
+		if tenant, err = s.tenantRepo.GetTenantByID(ctx, tenantID); err != nil {
+			return usage, err
+		}
+	}
+	usage.budget = tenant.TokenBudget
+	if usage.budget.Enabled() {
+		var err error
+		if usage.daily, usage.monthly, err = s.usedTokens(ctx, tenantID, now); err != nil {
+			return usage, err
+		}
+	}
+
+	s.mu.Lock()
+	s.cache[tenantID] = &usage
+	s.mu.Unlock()
+	return usage, nil
+}
+
+// usedTokens returns the tokens a tenant used on the UTC day and calendar
+// month of now.
+func (s *tokenUsageService) usedTokens(ctx context.Context, tenantID uint64, now time.Time) (int64, int64, error) {
+	now = now.UTC()
+	day := now.Format(types.TokenUsageDayLayout)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC).Format(types.TokenUsageDayLayout)
+	daily, err := s.repo.TotalTokens(ctx, tenantID, day, day)
+	if err != nil {
+		return 0, 0, err
+	}
+	monthly, err := s.repo.TotalTokens(ctx, tenantID, monthStart, day)
+	if err != nil {
+		return 0, 0, err
+	}
+	return daily, monthly, nil
+}
+
+// GetReport returns the usage of a tenant over a date range. The range
+// defaults to the last 30 days and the grouping to days.
+func (s *tokenUsageService) GetReport(
+	ctx context.Context, query *types.TokenUsageQuery,
+) (*types.TokenUsageReport, error) {
+	q := *query
+	if q.GroupBy == "" {
+		q.GroupBy = types.TokenUsageGroupByDay
+	}
+	if !q.GroupBy.IsValid() {
+		return nil, errors.NewBadRequestError(fmt.Sprintf("unsupported group_by: %s", q.GroupBy))
+	}
+
+	end := s.now().UTC()
+	if q.EndDay != "" {
+		parsed, err := time.Parse(types.TokenUsageDayLayout, q.EndDay)
+		if err != nil {
+			return nil, errors.NewBadRequestError("end_date must be formatted as YYYY-MM-DD")
+		}
+		end = parsed
+	}
+	start := end.AddDate(0, 0, 1-defaultTokenUsageReportDays)
+	if q.StartDay != "" {
+		parsed, err := time.Parse(types.TokenUsageDayLayout, q.StartDay)
+		if err != nil {
+			return nil, errors.NewBadRequestError("start_date must be formatted as YYYY-MM-DD")
+		}
+		start = parsed
+	}
+	q.StartDay = start.Format(types.TokenUsageDayLayout)
+	q.EndDay = end.Format(types.TokenUsageDayLayout)
+	if q.StartDay > q.EndDay {
+		return nil, errors.NewBadRequestError("start_date must not be after end_date")
+	}
+	if end.Sub(start) >= maxTokenUsageReportDays*24*time.Hour {
+		return nil, errors.NewBadRequestError(
+			fmt.Sprintf("the date range must not exceed %d days", maxTokenUsageReportDays))
+	}
+
+	items, err := s.repo.Summarize(ctx, &q)
+	if err != nil {
+		return nil, err
+	}
+	report := &types.TokenUsageReport{
+		StartDate: q.StartDay,
+		EndDate:   q.EndDay,
+		GroupBy:   q.GroupBy,
+		Items:     items,
+	}
+	for _, item := range items {
+		report.Total.Add(item)
+	}
+	report.Total.Key = "total"
+	return report, nil
+}
+
+// GetBudgetStatus returns the tenant's budget and its current usage, read
+// from the database rather than the budget check cache.
+func (s *tokenUsageService) GetBudgetStatus(
+	ctx context.Context, tenant *types.Tenant,
+) (*types.TokenBudgetStatus, error) {
+	daily, monthly, err := s.usedTokens(ctx, tenant.ID, s.now())
+	if err != nil {
+		return nil, err
+	}
+	status := &types.TokenBudgetStatus{DailyUsed: daily, MonthlyUsed: monthly}
+	if tenant.TokenBudget != nil {
+		status.Budget = *tenant.TokenBudget
+		status.Exceeded = tenant.TokenBudget.Exceeds(daily, monthly)
+	}
+
+	// A changed budget applies to the next check.
+	s.mu.Lock()
+	delete(s.cache, tenant.ID)
+	s.mu.Unlock()
+	return status, nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTokenUsageRepo keeps the total tokens per tenant and day.
+type fakeTokenUsageRepo struct {
+	tokens  map[uint64]map[string]int64
+	summary []types.TokenUsageSummary
+	query   *types.TokenUsageQuery
+}
+
+func (r *fakeTokenUsageRepo) AddUsage(ctx context.Context, usage *types.TokenUsageDaily) error {
+	if r.tokens[usage.TenantID] == nil {
+		r.tokens[usage.TenantID] = map[string]int64{}
+	}
+	r.tokens[usage.TenantID][usage.Day] += usage.PromptTokens + usage.CompletionTokens + usage.EmbeddingTokens
+	return nil
+}
+
+func (r *fakeTokenUsageRepo) Summarize(
+	ctx context.Context, query *types.TokenUsageQuery,
+) ([]types.TokenUsageSummary, error) {
+	r.query = query
+	return r.summary, nil
+}
+
+func (r *fakeTokenUsageRepo) TotalTokens(ctx context.Context, tenantID uint64, startDay, endDay string) (int64, error) {
+	var total int64
+	for day, tokens := range r.tokens[tenantID] {
+		if day >= startDay && day <= endDay {
+			total += tokens
+		}
+	}
+	return total, nil
+}
+
+type fakeBudgetTenantRepo struct {
+	interfaces.TenantRepository
+	tenant *types.Tenant
+}
+
+func (r *fakeBudgetTenantRepo) GetTenantByID(ctx context.Context, id uint64) (*types.Tenant, error) {
+	return r.tenant, nil
+}
+
+func TestTokenUsageService_CheckBudget(t *testing.T) {
+	repo := &fakeTokenUsageRepo{tokens: map[uint64]map[string]int64{
+		7: {"2026-10-01": 500, "2026-10-15": 300},
+	}}
+	tenant := &types.Tenant{ID: 7, TokenBudget: &types.TokenBudget{DailyTokens: 400, MonthlyTokens: 1000}}
+	svc := NewTokenUsageService(repo, &fakeBudgetTenantRepo{tenant: tenant}).(*tokenUsageService)
+	svc.now = func() time.Time { return time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC) }
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(7))
+
+	require.NoError(t, svc.CheckBudget(ctx))
+
+	svc.RecordUsage(ctx, &types.TokenUsageEvent{ModelID: "m", PromptTokens: 80, CompletionTokens: 20})
+	err := svc.CheckBudget(ctx)
+	require.Error(t, err, "usage recorded by the process counts before the cache expires")
+	assert.True(t, errors.Is(err, types.ErrTokenBudgetExceeded))
+
+	status, err := svc.GetBudgetStatus(ctx, tenant)
+	require.NoError(t, err)
+	assert.Equal(t, int64(400), status.DailyUsed)
+	assert.Equal(t, int64(900), status.MonthlyUsed)
+	assert.True(t, status.Exceeded)
+
+	tenant.TokenBudget = nil
+	assert.NoError(t, svc.CheckBudget(ctx), "no budget, no limit")
+	assert.NoError(t, svc.CheckBudget(context.Background()), "calls without a tenant are not limited")
+}
+
+func TestTokenUsageService_GetReport(t *testing.T) {
+	repo := &fakeTokenUsageRepo{summary: []types.TokenUsageSummary{
+		{Key: "2026-10-14", Requests: 2, PromptTokens: 10, TotalTokens: 10},
+		{Key: "2026-10-15", Requests: 1, EmbeddingTokens: 5, TotalTokens: 5},
+	}}
+	svc := NewTokenUsageService(repo, &fakeBudgetTenantRepo{}).(*tokenUsageService)
+	svc.now = func() time.Time { return time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC) }
+	ctx := context.Background()
+
+	report, err := svc.GetReport(ctx, &types.TokenUsageQuery{TenantID: 7})
+	require.NoError(t, err)
+	assert.Equal(t, "2026-09-16", report.StartDate)
+	assert.Equal(t, "2026-10-15", report.EndDate)
+	assert.Equal(t, types.TokenUsageGroupByDay, repo.query.GroupBy)
+	assert.Equal(t, int64(3), report.Total.Requests)
+	assert.Equal(t, int64(15), report.Total.TotalTokens)
+
+	_, err = svc.GetReport(ctx, &types.TokenUsageQuery{StartDay: "2026-10-15", EndDay: "2026-10-01"})
+	assert.Error(t, err)
+	_, err = svc.GetReport(ctx, &types.TokenUsageQuery{StartDay: "2024-01-01", EndDay: "2026-10-01"})
+	assert.Error(t, err)
+	_, err = svc.GetReport(ctx, &types.TokenUsageQuery{GroupBy: "user"})
+	assert.Error(t, err)
+}
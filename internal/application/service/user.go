@@ -441,6 +441,10 @@ func (s *userService) LoginWithOIDC(ctx context.Context, code, redirectURI strin
 		return &types.OIDCCallbackResponse{Success: false, Message: "Account is disabled"}, nil
 	}
 
+	// 同步外部组与组映射的成员关系，需在解析登录租户之前完成，
+	// 这样首次登录即可落到映射授予的租户。
+	s.syncSSOGroups(ctx, cfg, user, userInfo.Groups)
+
 	// Resolve target tenant once so the JWT claim and the tenant we
 	// return below stay in sync; see Login for the rationale.
 	resolvedTenantID := s.resolveLoginTenantID(ctx, user)
@@ -1121,6 +1125,7 @@ func (s *userService) resolveOIDCUserInfo(ctx context.Context, cfg *config.OIDCA
 	}
 	info.Username = extractClaimAsString(claims, cfg.UserInfoMapping.Username)
 	info.Email = extractClaimAsString(claims, cfg.UserInfoMapping.Email)
+	info.Groups = extractClaimAsStrings(claims, cfg.GroupsClaim)
 	if info.Username == "" {
 		info.Username = extractClaimAsString(claims, "preferred_username")
 	}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// syncSSOGroups records the user's external groups and applies the group
+// mappings. It runs on every SSO login so directory changes reach WeKnora the
+// next time the user signs in. Failures are logged rather than failing the
+// login: the user still gets in with the memberships they already had.
+func (s *userService) syncSSOGroups(
+	ctx context.Context,
+	cfg *config.OIDCAuthConfig,
+	user *types.User,
+	groups []string,
+) {
+	if !slices.Equal([]string(user.SSOGroups), groups) {
+		user.SSOGroups = groups
+		if err := s.userRepo.UpdateUser(ctx, user); err != nil {
+			logger.Warnf(ctx, "SSO login: failed to store groups of user %s: %v", user.ID, err)
+		}
+	}
+
+	for tenantID, role := range resolveSSOGroupRoles(cfg.GroupMappings, groups) {
+		if err := s.applySSOMembership(ctx, user.ID, tenantID, role); err != nil {
+			logger.Warnf(ctx, "SSO login: failed to grant %s in tenant %d to user %s: %v",
+				role, tenantID, user.ID, err)
+		}
+	}
+}
+
+// applySSOMembership makes the user a member of the tenant with at least the
+// given role. Memberships are added and roles raised, never lowered: a role a
+// tenant owner granted by hand outranks the directory.
+func (s *userService) applySSOMembership(
+	ctx context.Context,
+	userID string,
+	tenantID uint64,
+	role types.TenantRole,
+) error {
+	if _, err := s.tenantService.GetTenantByID(ctx, tenantID); err != nil {
+		return fmt.Errorf("tenant not found: %w", err)
+	}
+	member, err := s.memberService.GetMembership(ctx, userID, tenantID)
+	if err != nil {
+		return err
+	}
+	if member == nil {
+		if _, err := s.memberService.AddMember(ctx, userID, tenantID, role, nil); err != nil {
+			return err
+		}
+		logger.Infof(ctx, "SSO login: added user %s to tenant %d as %s", userID, tenantID, role)
+		return nil
+	}
+	if member.Role.Level() >= role.Level() {
+		return nil
+	}
+	if err := s.memberService.UpdateRole(ctx, userID, tenantID, role); err != nil {
+		return err
+	}
+	logger.Infof(ctx, "SSO login: raised user %s in tenant %d from %s to %s",
+		userID, tenantID, member.Role, role)
+	return nil
+}
+
+// resolveSSOGroupRoles returns the highest role the groups earn in each
+// mapped tenant.
+func resolveSSOGroupRoles(mappings []config.SSOGroupMapping, groups []string) map[uint64]types.TenantRole {
+	roles := make(map[uint64]types.TenantRole)
+	for _, mapping := range mappings {
+		role := types.TenantRole(mapping.Role)
+		if !role.IsValid() || role == types.TenantRoleOwner || !slices.Contains(groups, mapping.Group) {
+			continue
+		}
+		if current, ok := roles[mapping.TenantID]; !ok || role.Level() > current.Level() {
+			roles[mapping.TenantID] = role
+		}
+	}
+	return roles
+}
+
+// extractClaimAsStrings reads a group-like claim. Providers send a JSON array
+// of strings, or a single string that may be comma or space separated.
+// Empty entries are dropped and the result is sorted so it compares stably.
+func extractClaimAsStrings(claims map[string]interface{}, key string) []string {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil
+	}
+	var values []string
+	switch v := claims[key].(type) {
+	case []interface{}:
+		for _, item := range v {
+			if str, ok := item.(string); ok {
+				values = append(values, str)
+			}
+		}
+	case []string:
+		values = v
+	case string:
+		values = strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+
+	groups := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			groups = append(groups, value)
+		}
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	slices.Sort(groups)
+	return slices.Compact(groups)
+}
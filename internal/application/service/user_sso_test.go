@@ -0,0 +1,58 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// resolveSSOGroupRoles turns the user's external groups into the role to
+// hold in each mapped tenant. When several groups map into one tenant the
+// highest role wins, and owner is never granted even if a mapping slipped
+// past config validation.
+func TestResolveSSOGroupRoles(t *testing.T) {
+	mappings := []config.SSOGroupMapping{
+		{Group: "staff", TenantID: 1, Role: "viewer"},
+		{Group: "kb-admins", TenantID: 1, Role: "admin"},
+		{Group: "writers", TenantID: 2, Role: "contributor"},
+		{Group: "founders", TenantID: 3, Role: "owner"},
+		{Group: "staff", TenantID: 4, Role: "bogus"},
+	}
+
+	got := resolveSSOGroupRoles(mappings, []string{"founders", "kb-admins", "staff"})
+	want := map[uint64]types.TenantRole{1: types.TenantRoleAdmin}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if got := resolveSSOGroupRoles(mappings, nil); len(got) != 0 {
+		t.Fatalf("no groups should map to nothing, got %v", got)
+	}
+}
+
+func TestExtractClaimAsStrings(t *testing.T) {
+	cases := []struct {
+		name  string
+		claim interface{}
+		want  []string
+	}{
+		{"json array", []interface{}{"b", "a", 3, " ", "a"}, []string{"a", "b"}},
+		{"string slice", []string{"x"}, []string{"x"}},
+		{"comma and space separated", "ops, dev  qa", []string{"dev", "ops", "qa"}},
+		{"missing claim", nil, nil},
+		{"unsupported type", float64(1), nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := map[string]interface{}{}
+			if tc.claim != nil {
+				claims["groups"] = tc.claim
+			}
+			if got := extractClaimAsStrings(claims, "groups"); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
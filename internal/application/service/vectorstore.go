@@ -2,11 +2,13 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	stderrors "errors"
 	"fmt"
 	"os"
 	"time"
 
+	"github.com/Tencent/WeKnora/internal/application/service/retriever"
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -262,6 +264,212 @@ func (s *vectorStoreService) SaveDetectedVersion(ctx context.Context, store *typ
 	return s.repo.UpdateConnectionConfig(ctx, &updated)
 }
 
+// ReindexStore rebuilds the store's collections using the engine registered
+// for it. Long-running: every row of every collection is copied once.
+func (s *vectorStoreService) ReindexStore(ctx context.Context, store *types.VectorStore) (*types.ReindexReport, error) {
+	if s.storeRegistry == nil {
+		return nil, errors.NewServiceUnavailableError("vector store registry is not available")
+	}
+	engine, err := s.storeRegistry.GetByStoreID(store.ID)
+	if err != nil {
+		return nil, errors.NewVectorStoreUnavailableError("vector store is not registered; check its connection and retry")
+	}
+	reindexer, ok := engine.(interfaces.CollectionReindexer)
+	if !ok {
+		return nil, errors.NewValidationError(
+			fmt.Sprintf("engine %s does not support reindex", store.EngineType))
+	}
+
+	logger.Infof(ctx, "Reindexing vector store: tenant=%d, id=%s, engine=%s",
+		store.TenantID, store.ID, store.EngineType)
+	report, err := reindexer.ReindexCollections(ctx)
+	if stderrors.Is(err, retriever.ErrReindexUnsupported) {
+		return nil, errors.NewValidationError(
+			fmt.Sprintf("engine %s does not support reindex", store.EngineType))
+	}
+	if err != nil {
+		logger.Warnf(ctx, "Reindex of vector store %s failed: %v", store.ID, err)
+		return nil, errors.NewInternalServerError("failed to reindex vector store")
+	}
+	logger.Infof(ctx, "Reindexed vector store %s: collections=%d, failed=%d",
+		store.ID, len(report.Collections), report.Failed())
+	return report, nil
+}
+
+// DedupStore collapses duplicate index rows that retried writes left in
+// the store before Save used deterministic row IDs.
+func (s *vectorStoreService) DedupStore(ctx context.Context, store *types.VectorStore) (*types.DedupReport, error) {
+	if s.storeRegistry == nil {
+		return nil, errors.NewServiceUnavailableError("vector store registry is not available")
+	}
+	engine, err := s.storeRegistry.GetByStoreID(store.ID)
+	if err != nil {
+		return nil, errors.NewVectorStoreUnavailableError("vector store is not registered; check its connection and retry")
+	}
+	deduplicator, ok := engine.(interfaces.IndexDeduplicator)
+	if !ok {
+		return nil, errors.NewValidationError(
+			fmt.Sprintf("engine %s does not support deduplication", store.EngineType))
+	}
+
+	logger.Infof(ctx, "Deduplicating vector store: tenant=%d, id=%s, engine=%s",
+		store.TenantID, store.ID, store.EngineType)
+	report, err := deduplicator.DeduplicateIndices(ctx)
+	if stderrors.Is(err, retriever.ErrDedupUnsupported) {
+		return nil, errors.NewValidationError(
+			fmt.Sprintf("engine %s does not support deduplication", store.EngineType))
+	}
+	if err != nil {
+		logger.Warnf(ctx, "Deduplication of vector store %s failed: %v", store.ID, err)
+		return nil, errors.NewInternalServerError("failed to deduplicate vector store")
+	}
+	logger.Infof(ctx, "Deduplicated vector store %s: collections=%d, failed=%d",
+		store.ID, len(report.Collections), report.Failed())
+	return report, nil
+}
+
+// MigrateStoreModelSpaces moves the tenant's vectors out of the legacy
+// per-dimension collections of store into per-embedding-model collections.
+// Only knowledge bases of tenantID that have an embedding model are passed
+// to the engine, so on a shared env store other tenants' rows stay put.
+func (s *vectorStoreService) MigrateStoreModelSpaces(
+	ctx context.Context, tenantID uint64, store *types.VectorStore,
+) (*types.ModelSpaceMigrationReport, error) {
+	if s.storeRegistry == nil {
+		return nil, errors.NewServiceUnavailableError("vector store registry is not available")
+	}
+	engine, err := s.resolveStoreEngine(store)
+	if err != nil {
+		return nil, errors.NewVectorStoreUnavailableError("vector store is not registered; check its connection and retry")
+	}
+	migrator, ok := engine.(interfaces.ModelSpaceMigrator)
+	if !ok {
+		return nil, errors.NewValidationError(
+			fmt.Sprintf("engine %s does not support model space migration", store.EngineType))
+	}
+
+	kbs, err := s.kbRepo.ListKnowledgeBasesByTenantID(ctx, tenantID)
+	if err != nil {
+		logger.Warnf(ctx, "List knowledge bases for model space migration failed: %v", err)
+		return nil, errors.NewInternalServerError("failed to list knowledge bases")
+	}
+	kbModels := make(map[string]string, len(kbs))
+	for _, kb := range kbs {
+		if kb.EmbeddingModelID != "" && kb.IsVectorEnabled() {
+			kbModels[kb.ID] = kb.EmbeddingModelID
+		}
+	}
+
+	logger.Infof(ctx, "Migrating vector store model spaces: tenant=%d, id=%s, engine=%s, kbs=%d",
+		tenantID, store.ID, store.EngineType, len(kbModels))
+	report, err := migrator.MigrateModelSpaces(ctx, kbModels)
+	if stderrors.Is(err, retriever.ErrModelSpaceMigrationUnsupported) {
+		return nil, errors.NewValidationError(
+			fmt.Sprintf("engine %s does not support model space migration", store.EngineType))
+	}
+	if err != nil {
+		logger.Warnf(ctx, "Model space migration of vector store %s failed: %v", store.ID, err)
+		return nil, errors.NewInternalServerError("failed to migrate vector store model spaces")
+	}
+	logger.Infof(ctx, "Migrated vector store %s model spaces: collections=%d, failed=%d",
+		store.ID, len(report.Collections), report.Failed())
+	return report, nil
+}
+
+// ValidateFilter compiles a metadata filter condition with the engine
+// serving store so malformed filters are reported before any query runs.
+func (s *vectorStoreService) ValidateFilter(
+	ctx context.Context, store *types.VectorStore, filter json.RawMessage,
+) (*types.FilterValidation, error) {
+	if s.storeRegistry == nil {
+		return nil, errors.NewServiceUnavailableError("vector store registry is not available")
+	}
+	engine, err := s.resolveStoreEngine(store)
+	if err != nil {
+		return nil, errors.NewVectorStoreUnavailableError("vector store is not registered; check its connection and retry")
+	}
+	validator, ok := engine.(interfaces.FilterValidator)
+	if !ok {
+		return nil, errors.NewValidationError(
+			fmt.Sprintf("engine %s does not support filter validation", store.EngineType))
+	}
+
+	result, err := validator.ValidateFilter(ctx, filter)
+	if stderrors.Is(err, retriever.ErrFilterValidationUnsupported) {
+		return nil, errors.NewValidationError(
+			fmt.Sprintf("engine %s does not support filter validation", store.EngineType))
+	}
+	if err != nil {
+		logger.Warnf(ctx, "Filter validation on vector store %s failed: %v", store.ID, err)
+		return nil, errors.NewInternalServerError("failed to validate filter")
+	}
+	return result, nil
+}
+
+// GetCapabilities reports what the engine serving store supports so
+// clients can adapt filters and top-k before sending a query.
+func (s *vectorStoreService) GetCapabilities(
+	ctx context.Context, store *types.VectorStore,
+) (*types.EngineCapabilities, error) {
+	if s.storeRegistry == nil {
+		return nil, errors.NewServiceUnavailableError("vector store registry is not available")
+	}
+	engine, err := s.resolveStoreEngine(store)
+	if err != nil {
+		return nil, errors.NewVectorStoreUnavailableError("vector store is not registered; check its connection and retry")
+	}
+	reporter, ok := engine.(interfaces.CapabilityReporter)
+	if !ok {
+		return nil, errors.NewValidationError(
+			fmt.Sprintf("engine %s does not report capabilities", store.EngineType))
+	}
+	capabilities := reporter.Capabilities()
+	return &capabilities, nil
+}
+
+// GetLoadState reports collection load progress of the engine serving
+// store, so operators can tell whether searches will wait on a load.
+func (s *vectorStoreService) GetLoadState(
+	ctx context.Context, store *types.VectorStore,
+) (*types.LoadStateReport, error) {
+	if s.storeRegistry == nil {
+		return nil, errors.NewServiceUnavailableError("vector store registry is not available")
+	}
+	engine, err := s.resolveStoreEngine(store)
+	if err != nil {
+		return nil, errors.NewVectorStoreUnavailableError("vector store is not registered; check its connection and retry")
+	}
+	reporter, ok := engine.(interfaces.LoadStateReporter)
+	if !ok {
+		return nil, errors.NewValidationError(
+			fmt.Sprintf("engine %s does not report collection load state", store.EngineType))
+	}
+
+	report, err := reporter.LoadStates(ctx)
+	if stderrors.Is(err, retriever.ErrLoadStateUnsupported) {
+		return nil, errors.NewValidationError(
+			fmt.Sprintf("engine %s does not report collection load state", store.EngineType))
+	}
+	if err != nil {
+		logger.Warnf(ctx, "Load state of vector store %s failed: %v", store.ID, err)
+		return nil, errors.NewInternalServerError("failed to get vector store load state")
+	}
+	return report, nil
+}
+
+// resolveStoreEngine returns the engine serving store. DB stores are
+// registered by ID; env stores are registered by engine type only.
+func (s *vectorStoreService) resolveStoreEngine(store *types.VectorStore) (interfaces.RetrieveEngineService, error) {
+	if !types.IsEnvStoreID(store.ID) {
+		return s.storeRegistry.GetByStoreID(store.ID)
+	}
+	registry, ok := s.storeRegistry.(interfaces.RetrieveEngineRegistry)
+	if !ok {
+		return nil, fmt.Errorf("registry cannot resolve env store %s", store.ID)
+	}
+	return registry.GetRetrieveEngineService(store.EngineType)
+}
+
 // ResolveStoreView returns the API-safe display projection of a single
 // store ID for embedding in another resource's response (typically a KB).
 //
@@ -163,6 +163,12 @@ func (m *mockEngineService) DeleteBySourceIDList(_ context.Context, _ []string,
 func (m *mockEngineService) DeleteByKnowledgeIDList(_ context.Context, _ []string, _ int, _ string) error {
 	return nil
 }
+func (m *mockEngineService) DeleteByKnowledgeBaseIDList(_ context.Context, _ []string, _ int, _ string) error {
+	return nil
+}
+func (m *mockEngineService) DeleteByTagIDList(_ context.Context, _ []string, _ int, _ string) error {
+	return nil
+}
 func (m *mockEngineService) BatchUpdateChunkEnabledStatus(_ context.Context, _ map[string]bool) error {
 	return nil
 }
@@ -845,6 +851,8 @@ CREATE TABLE IF NOT EXISTS knowledge_bases (
     vector_store_id VARCHAR(36),
     wiki_config TEXT,
     indexing_strategy TEXT,
+    index_generation INTEGER NOT NULL DEFAULT 0,
+    pending_index_generation INTEGER NOT NULL DEFAULT 0,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     deleted_at DATETIME
@@ -957,6 +965,17 @@ func (r *realKBRepo) TogglePinKnowledgeBase(_ context.Context, _ string, _ uint6
 func (r *realKBRepo) ListUserKBPinIDs(_ context.Context, _ uint64, _ string) (map[string]time.Time, error) {
 	return map[string]time.Time{}, nil
 }
+func (r *realKBRepo) BeginIndexGeneration(_ context.Context, _ string) (int64, error) {
+	return 0, nil
+}
+func (r *realKBRepo) AbortIndexGeneration(_ context.Context, _ string, _ int64) error {
+	return nil
+}
+func (r *realKBRepo) SwitchIndexGeneration(
+	_ context.Context, _ string, _ int64, _ []types.ChunkType, _ string,
+) ([]string, error) {
+	return nil, nil
+}
 func (r *realKBRepo) SetUserKBPin(_ context.Context, _ uint64, _ string, _ string, _ bool) (*time.Time, error) {
 	return nil, nil
 }
@@ -33,6 +33,7 @@ type Config struct {
 	PromptTemplates *PromptTemplatesConfig `yaml:"prompt_templates" json:"prompt_templates"`
 	IM              *IMConfig              `yaml:"im"               json:"im"`
 	Agent           *AgentConfig           `yaml:"agent"            json:"agent"`
+	RateLimit       *RateLimitConfig       `yaml:"rate_limit"       json:"rate_limit"`
 	// FrontendBaseURL is the externally-visible origin of the SPA, used
 	// to compose absolute share-link URLs. Empty falls back to a host-
 	// relative URL ("/register?token=…") which the SPA then resolves
@@ -127,6 +128,29 @@ type ConversationConfig struct {
 	// IntentSystemPrompts maps intent values (e.g. "greeting", "chitchat") to
 	// system prompt text. Populated by backfill from IntentPrompts templates.
 	IntentSystemPrompts map[string]string `yaml:"-" json:"-"`
+
+	// Hooks are HTTP webhooks invoked at knowledge QA pipeline events
+	Hooks []PipelineHookConfig `yaml:"hooks" json:"hooks"`
+}
+
+// PipelineHookConfig configures an HTTP webhook invoked at chat pipeline
+// events. The pipeline state is posted as JSON; a non-2xx response or a
+// timeout is a failure.
+type PipelineHookConfig struct {
+	Name string `yaml:"name" json:"name"`
+	URL  string `yaml:"url"  json:"url"`
+	// Events are pipeline event types such as "chunk_rerank"
+	Events []string `yaml:"events" json:"events"`
+	// Phase is "before" or "after" (default) the event's built-in plugins
+	Phase string `yaml:"phase" json:"phase"`
+	// Priority orders the hooks of an event, lowest first
+	Priority int `yaml:"priority" json:"priority"`
+	// TimeoutMs bounds each call, 5000 by default
+	TimeoutMs int `yaml:"timeout_ms" json:"timeout_ms"`
+	// FailurePolicy is "skip" (default) to continue or "abort" to fail the
+	// request when the hook fails
+	FailurePolicy string            `yaml:"failure_policy" json:"failure_policy"`
+	Headers       map[string]string `yaml:"headers"        json:"headers"`
 }
 
 // SummaryConfig 摘要配置
@@ -255,6 +279,25 @@ type AuditConfig struct {
 	RetentionDays int `yaml:"retention_days" json:"retention_days"`
 }
 
+// RateLimitConfig bounds what each tenant may do through the API. The
+// counters live in Redis when it is configured, so the limits hold across
+// instances; without Redis every instance enforces them on its own.
+// A zero limit is no limit, which is the default for every field.
+type RateLimitConfig struct {
+	// RequestsPerMinute caps authenticated /api/v1 requests per tenant
+	// over a sliding minute.
+	RequestsPerMinute int `yaml:"requests_per_minute" json:"requests_per_minute"`
+	// APIKeyRequestsPerMinute caps the requests made with one X-API-Key
+	// over a sliding minute, on top of the tenant cap.
+	APIKeyRequestsPerMinute int `yaml:"api_key_requests_per_minute" json:"api_key_requests_per_minute"`
+	// ConcurrentChats caps the knowledge-chat and agent-chat requests a
+	// tenant may have in flight at once.
+	ConcurrentChats int `yaml:"concurrent_chats" json:"concurrent_chats"`
+	// DailyUploads caps the documents (file, URL and manual knowledge) a
+	// tenant may add over a sliding 24 hours.
+	DailyUploads int `yaml:"daily_uploads" json:"daily_uploads"`
+}
+
 // AuthConfig governs the user authentication entry points.
 type AuthConfig struct {
 	// RegistrationMode controls who may call POST /auth/register.
@@ -301,6 +344,53 @@ type OIDCAuthConfig struct {
 	UserInfoEndpoint      string               `yaml:"user_info_endpoint"     json:"user_info_endpoint"`
 	Scopes                []string             `yaml:"scopes"                 json:"scopes"`
 	UserInfoMapping       *OIDCUserInfoMapping `yaml:"user_info_mapping"      json:"user_info_mapping"`
+	// GroupsClaim is the claim carrying the user's groups, "groups" by default
+	GroupsClaim string `yaml:"groups_claim"           json:"groups_claim"`
+	// GroupMappings grant tenant memberships to the members of external groups
+	GroupMappings []SSOGroupMapping `yaml:"group_mappings"         json:"group_mappings"`
+}
+
+// SSOGroupMapping grants Role in TenantID to the members of an external group.
+// Mappings are applied on every SSO login; they add memberships and raise
+// roles but never lower or remove what a tenant owner set by hand.
+type SSOGroupMapping struct {
+	Group    string `yaml:"group"     json:"group"`
+	TenantID uint64 `yaml:"tenant_id" json:"tenant_id"`
+	Role     string `yaml:"role"      json:"role"`
+}
+
+// ssoMappableRoles are the tenant roles a group mapping may grant. Owner is
+// left out: ownership is handed over by an owner, not by a directory.
+var ssoMappableRoles = map[string]bool{"viewer": true, "contributor": true, "admin": true}
+
+// parseSSOGroupMappings parses "group=tenant_id:role" entries separated by
+// commas or semicolons, e.g. "kb-admins=10000:admin;staff=10000:viewer".
+func parseSSOGroupMappings(value string) ([]SSOGroupMapping, error) {
+	var mappings []SSOGroupMapping
+	for _, entry := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ';' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		group, target, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid group mapping %q, want group=tenant_id:role", entry)
+		}
+		tenant, role, ok := strings.Cut(target, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid group mapping %q, want group=tenant_id:role", entry)
+		}
+		tenantID, err := strconv.ParseUint(strings.TrimSpace(tenant), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tenant id in group mapping %q: %w", entry, err)
+		}
+		mappings = append(mappings, SSOGroupMapping{
+			Group:    strings.TrimSpace(group),
+			TenantID: tenantID,
+			Role:     strings.TrimSpace(role),
+		})
+	}
+	return mappings, nil
 }
 
 // PromptTemplateI18n holds localized name and description for a prompt template.
@@ -565,6 +655,7 @@ func LoadConfig() (*Config, error) {
 	applyKnowledgeBaseEnvOverrides(&cfg)
 	applyAuthAndTenantDefaults(&cfg)
 	applyAuditDefaults(&cfg)
+	applyRateLimitDefaults(&cfg)
 
 	if err := ValidateConfig(&cfg); err != nil {
 		return nil, err
@@ -607,6 +698,15 @@ func ValidateConfig(cfg *Config) error {
 			(strings.TrimSpace(cfg.OIDCAuth.AuthorizationEndpoint) == "" || strings.TrimSpace(cfg.OIDCAuth.TokenEndpoint) == "") {
 			errs = append(errs, "oidc_auth.discovery_url or both oidc_auth.authorization_endpoint and oidc_auth.token_endpoint are required when OIDC is enabled")
 		}
+		for i, mapping := range cfg.OIDCAuth.GroupMappings {
+			if strings.TrimSpace(mapping.Group) == "" || mapping.TenantID == 0 {
+				errs = append(errs, fmt.Sprintf("oidc_auth.group_mappings[%d] needs a group and a tenant_id", i))
+			}
+			if !ssoMappableRoles[mapping.Role] {
+				errs = append(errs, fmt.Sprintf("oidc_auth.group_mappings[%d].role must be viewer, contributor or admin, got %q",
+					i, mapping.Role))
+			}
+		}
 	}
 
 	if cfg.Auth != nil {
@@ -622,6 +722,19 @@ func ValidateConfig(cfg *Config) error {
 			cfg.Audit.RetentionDays))
 	}
 
+	if rl := cfg.RateLimit; rl != nil {
+		for name, value := range map[string]int{
+			"requests_per_minute":         rl.RequestsPerMinute,
+			"api_key_requests_per_minute": rl.APIKeyRequestsPerMinute,
+			"concurrent_chats":            rl.ConcurrentChats,
+			"daily_uploads":               rl.DailyUploads,
+		} {
+			if value < 0 {
+				errs = append(errs, fmt.Sprintf("rate_limit.%s must be >= 0 (got %d); use 0 for no limit", name, value))
+			}
+		}
+	}
+
 	if cfg.Conversation != nil {
 		if cfg.Conversation.EmbeddingTopK < 0 {
 			errs = append(errs, "conversation.embedding_top_k must be >= 0")
@@ -705,6 +818,16 @@ func applyOIDCEnvOverrides(cfg *Config) {
 	if value := strings.TrimSpace(os.Getenv("OIDC_USER_INFO_MAPPING_EMAIL")); value != "" {
 		cfg.OIDCAuth.UserInfoMapping.Email = value
 	}
+	if value := strings.TrimSpace(os.Getenv("OIDC_AUTH_GROUPS_CLAIM")); value != "" {
+		cfg.OIDCAuth.GroupsClaim = value
+	}
+	if value := strings.TrimSpace(os.Getenv("OIDC_AUTH_GROUP_MAPPINGS")); value != "" {
+		if mappings, err := parseSSOGroupMappings(value); err != nil {
+			fmt.Printf("Warning: ignoring OIDC_AUTH_GROUP_MAPPINGS: %v\n", err)
+		} else {
+			cfg.OIDCAuth.GroupMappings = mappings
+		}
+	}
 
 	if cfg.OIDCAuth.ProviderDisplayName == "" {
 		cfg.OIDCAuth.ProviderDisplayName = "OIDC"
@@ -718,6 +841,9 @@ func applyOIDCEnvOverrides(cfg *Config) {
 	if cfg.OIDCAuth.UserInfoMapping.Email == "" {
 		cfg.OIDCAuth.UserInfoMapping.Email = "email"
 	}
+	if cfg.OIDCAuth.GroupsClaim == "" {
+		cfg.OIDCAuth.GroupsClaim = "groups"
+	}
 	if cfg.OIDCAuth.DiscoveryURL == "" && cfg.OIDCAuth.IssuerURL != "" {
 		cfg.OIDCAuth.DiscoveryURL = strings.TrimRight(cfg.OIDCAuth.IssuerURL, "/") + "/.well-known/openid-configuration"
 	}
@@ -869,6 +995,32 @@ func applyAuditDefaults(cfg *Config) {
 	}
 }
 
+// applyRateLimitDefaults makes sure the RateLimit section exists (all
+// limits off when it is omitted) and applies the env overrides.
+//
+// Env overrides (when set and parseable; negative values are ignored):
+//   - WEKNORA_RATE_LIMIT_REQUESTS_PER_MINUTE
+//   - WEKNORA_RATE_LIMIT_API_KEY_REQUESTS_PER_MINUTE
+//   - WEKNORA_RATE_LIMIT_CONCURRENT_CHATS
+//   - WEKNORA_RATE_LIMIT_DAILY_UPLOADS
+func applyRateLimitDefaults(cfg *Config) {
+	if cfg.RateLimit == nil {
+		cfg.RateLimit = &RateLimitConfig{}
+	}
+	for env, field := range map[string]*int{
+		"WEKNORA_RATE_LIMIT_REQUESTS_PER_MINUTE":         &cfg.RateLimit.RequestsPerMinute,
+		"WEKNORA_RATE_LIMIT_API_KEY_REQUESTS_PER_MINUTE": &cfg.RateLimit.APIKeyRequestsPerMinute,
+		"WEKNORA_RATE_LIMIT_CONCURRENT_CHATS":            &cfg.RateLimit.ConcurrentChats,
+		"WEKNORA_RATE_LIMIT_DAILY_UPLOADS":               &cfg.RateLimit.DailyUploads,
+	} {
+		if value := strings.TrimSpace(os.Getenv(env)); value != "" {
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+				*field = n
+			}
+		}
+	}
+}
+
 // into actual prompt text content. Only xxx_id fields are used;
 // no fallback to default templates.
 func backfillConversationDefaults(cfg *Config) {
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSSOGroupMappings(t *testing.T) {
+	got, err := parseSSOGroupMappings(" kb-admins=10000:admin; staff = 10000 : viewer ,ops=7:contributor,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []SSOGroupMapping{
+		{Group: "kb-admins", TenantID: 10000, Role: "admin"},
+		{Group: "staff", TenantID: 10000, Role: "viewer"},
+		{Group: "ops", TenantID: 7, Role: "contributor"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+
+	for _, bad := range []string{"kb-admins", "kb-admins=admin", "kb-admins=x:admin"} {
+		if _, err := parseSSOGroupMappings(bad); err == nil {
+			t.Errorf("expected an error for %q", bad)
+		}
+	}
+}
+
+// TestValidateConfig_GroupMappings keeps directories from handing out
+// ownership: owner and unknown roles are rejected at startup.
+func TestValidateConfig_GroupMappings(t *testing.T) {
+	cfg := &Config{OIDCAuth: &OIDCAuthConfig{
+		Enable:       true,
+		ClientID:     "id",
+		ClientSecret: "secret",
+		DiscoveryURL: "http://idp/.well-known/openid-configuration",
+		GroupMappings: []SSOGroupMapping{
+			{Group: "staff", TenantID: 1, Role: "viewer"},
+		},
+	}}
+	if err := ValidateConfig(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cfg.OIDCAuth.GroupMappings = []SSOGroupMapping{
+		{Group: "founders", TenantID: 1, Role: "owner"},
+		{Group: "", TenantID: 1, Role: "viewer"},
+	}
+	err := ValidateConfig(cfg)
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{"group_mappings[0].role", "group_mappings[1] needs a group"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
@@ -27,6 +27,9 @@ import (
 	"github.com/panjf2000/ants/v2"
 	"github.com/qdrant/go-client/qdrant"
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/dig"
 	"google.golang.org/grpc"
 	"gorm.io/driver/postgres"
@@ -36,6 +39,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/agent/approval"
 	"github.com/Tencent/WeKnora/internal/application/repository"
 	memoryRepo "github.com/Tencent/WeKnora/internal/application/repository/memory/neo4j"
+	memoryRelationalRepo "github.com/Tencent/WeKnora/internal/application/repository/memory/relational"
 	dorisRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/doris"
 	elasticsearchRepoV7 "github.com/Tencent/WeKnora/internal/application/repository/retriever/elasticsearch/v7"
 	elasticsearchRepoV8 "github.com/Tencent/WeKnora/internal/application/repository/retriever/elasticsearch/v8"
@@ -44,6 +48,7 @@ import (
 	openSearchRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/opensearch"
 	postgresRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/postgres"
 	qdrantRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/qdrant"
+	graphRelationalRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/relational"
 	sqliteRetrieverRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/sqlite"
 	tencentVectorDBRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/tencentvectordb"
 	weaviateRepo "github.com/Tencent/WeKnora/internal/application/repository/retriever/weaviate"
@@ -55,7 +60,11 @@ import (
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/database"
 	"github.com/Tencent/WeKnora/internal/datasource"
+	bucketConnector "github.com/Tencent/WeKnora/internal/datasource/connector/bucket"
+	confluenceConnector "github.com/Tencent/WeKnora/internal/datasource/connector/confluence"
 	feishuConnector "github.com/Tencent/WeKnora/internal/datasource/connector/feishu"
+	gitConnector "github.com/Tencent/WeKnora/internal/datasource/connector/git"
+	imapConnector "github.com/Tencent/WeKnora/internal/datasource/connector/imap"
 	notionConnector "github.com/Tencent/WeKnora/internal/datasource/connector/notion"
 	rssConnector "github.com/Tencent/WeKnora/internal/datasource/connector/rss"
 	yuqueConnector "github.com/Tencent/WeKnora/internal/datasource/connector/yuque"
@@ -72,15 +81,18 @@ import (
 	"github.com/Tencent/WeKnora/internal/im/wechat"
 	"github.com/Tencent/WeKnora/internal/im/wecom"
 	"github.com/Tencent/WeKnora/internal/infrastructure/docparser"
+	"github.com/Tencent/WeKnora/internal/infrastructure/filescan"
 	infra_web_search "github.com/Tencent/WeKnora/internal/infrastructure/web_search"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/mcp"
 	"github.com/Tencent/WeKnora/internal/models/chat"
 	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/models/usage"
 	"github.com/Tencent/WeKnora/internal/models/utils/ollama"
 	"github.com/Tencent/WeKnora/internal/router"
 	"github.com/Tencent/WeKnora/internal/stream"
 	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/tracing/telemetry"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
@@ -109,12 +121,15 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	logger.Debugf(ctx, "[Container] Registering core infrastructure...")
 	must(container.Provide(config.LoadConfig))
 	must(container.Provide(initLangfuse))
+	must(container.Provide(initTelemetry))
 	must(container.Provide(initDatabase))
 	must(container.Provide(initFileService))
+	must(container.Provide(filescan.NewFromEnv))
 	must(container.Provide(initRedisClient))
 	must(container.Provide(initAntsPool))
 
 	must(container.Invoke(registerLangfuseCleanup))
+	must(container.Invoke(registerTelemetryCleanup))
 
 	// Register goroutine pool cleanup handler
 	must(container.Invoke(registerPoolCleanup))
@@ -145,14 +160,22 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(repository.NewKnowledgeSpanRepository))
 	must(container.Provide(repository.NewChunkRepository))
 	must(container.Provide(repository.NewKnowledgeTagRepository))
+	must(container.Provide(repository.NewTagAccessRuleRepository))
+	must(container.Provide(repository.NewAPIKeyRepository))
+	must(container.Provide(repository.NewRetentionRepository))
+	must(container.Provide(repository.NewRetrievalEvalRepository))
 	must(container.Provide(repository.NewSessionRepository))
 	must(container.Provide(repository.NewMessageRepository))
+	must(container.Provide(repository.NewFeedbackRepository))
+	must(container.Provide(repository.NewExperimentRepository))
+	must(container.Provide(repository.NewAnalyticsRepository))
+	must(container.Provide(repository.NewKnowledgeGapRepository))
 	must(container.Provide(repository.NewModelRepository))
 	must(container.Provide(repository.NewUserRepository))
 	must(container.Provide(repository.NewAuthTokenRepository))
 	must(container.Provide(repository.NewSystemSettingRepository))
-	must(container.Provide(neo4jRepo.NewNeo4jRepository))
-	must(container.Provide(memoryRepo.NewMemoryRepository))
+	must(container.Provide(initGraphRepository))
+	must(container.Provide(initMemoryRepository))
 	must(container.Provide(repository.NewMCPServiceRepository))
 	must(container.Provide(repository.NewMCPToolApprovalRepository))
 	must(container.Provide(repository.NewMCPOAuthRepository))
@@ -179,6 +202,8 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	// Business service layer
 	logger.Debugf(ctx, "[Container] Registering business services...")
 	must(container.Provide(service.NewTenantService))
+	must(container.Provide(service.NewAPIKeyService))
+	must(container.Provide(service.NewRetentionService))
 	must(container.Provide(service.NewTenantMemberService))
 	must(container.Provide(service.NewTenantInvitationService))
 	must(container.Provide(service.NewAuditLogService))
@@ -195,6 +220,8 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(service.NewModelService))
 	must(container.Provide(service.NewDatasetService))
 	must(container.Provide(service.NewEvaluationService))
+	must(container.Provide(service.NewRetrievalEvalService))
+	must(container.Provide(service.NewExperimentService))
 	must(container.Provide(service.NewUserService))
 	must(container.Provide(service.NewSystemSettingService))
 	must(container.Provide(service.NewWeKnoraCloudService))
@@ -206,15 +233,26 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(service.NewKnowledgePostProcessService, dig.Name("knowledgePostProcess")))
 
 	must(container.Provide(service.NewMessageService))
+	must(container.Provide(service.NewFeedbackService))
+	must(container.Provide(service.NewKnowledgeGapService))
+	must(container.Provide(service.NewAnalyticsService))
+	must(container.Provide(service.NewHealthService))
 	must(container.Provide(service.NewMCPServiceService))
 	must(container.Provide(service.NewMCPToolApprovalService))
 	must(container.Provide(service.NewCustomAgentService))
 	must(container.Provide(service.NewUserResourceFavoriteService))
 	must(container.Provide(memoryService.NewMemoryService))
+	must(container.Provide(memoryService.NewExtractionHandler, dig.Name("memoryExtractor")))
 	must(container.Provide(service.NewWikiPageService))
 	must(container.Provide(service.NewWikiLogEntryService))
 	must(container.Provide(service.NewWikiIngestService, dig.Name("wikiIngest")))
 	must(container.Provide(service.NewWikiLintService))
+	must(container.Provide(service.NewGlossaryService))
+	must(container.Provide(service.NewGraphCommunityService))
+	must(container.Provide(service.NewDuplicateService))
+	must(container.Provide(service.NewAutoTagService))
+	must(container.Provide(service.NewTagScopeService))
+	must(container.Provide(service.NewIndexMigrationService))
 	must(container.Provide(service.NewEmbedChannelService))
 
 	// Web search service (needed by AgentService)
@@ -228,6 +266,14 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(retriever.NewVectorStoreRepoOwnership))
 	must(container.Provide(service.NewWebSearchService))
 	must(container.Provide(service.NewWebSearchProviderService))
+	must(container.Provide(repository.NewSQLConnectionRepository))
+	must(container.Provide(service.NewSQLConnectionService))
+	must(container.Provide(repository.NewTokenUsageRepository))
+	must(container.Provide(repository.NewKnowledgeVersionRepository))
+	must(container.Provide(repository.NewKBSnapshotRepository))
+	must(container.Provide(repository.NewDuplicateReportRepository))
+	must(container.Provide(service.NewTokenUsageService))
+	must(container.Invoke(registerTokenUsageRecorder))
 	must(container.Provide(NewEngineFactory))
 	// StoreRegistry: same instance as RetrieveEngineRegistry, exposed as StoreRegistry interface.
 	// NewRetrieveEngineRegistry always returns *retriever.RetrieveEngineRegistry which implements both.
@@ -287,6 +333,21 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	logger.Debugf(ctx, "[Container] Data source sync framework registered")
 	must(container.Invoke(startAuditLogRetention))
 	logger.Debugf(ctx, "[Container] Audit log retention runner registered")
+	must(container.Provide(service.NewTempFileCleanupRunner))
+	must(container.Invoke(startTempFileCleanup))
+	logger.Debugf(ctx, "[Container] Temp file cleanup runner registered")
+	must(container.Provide(service.NewKBSnapshotScheduler))
+	must(container.Invoke(startKBSnapshotScheduler))
+	logger.Debugf(ctx, "[Container] KB snapshot scheduler registered")
+	must(container.Provide(service.NewRetentionScheduler))
+	must(container.Invoke(startRetentionScheduler))
+	logger.Debugf(ctx, "[Container] Retention scheduler registered")
+	must(container.Provide(memoryService.NewConsolidationRunner))
+	must(container.Invoke(startMemoryConsolidation))
+	logger.Debugf(ctx, "[Container] Memory consolidation runner registered")
+	must(container.Provide(memoryService.NewForgettingRunner))
+	must(container.Invoke(startMemoryForgetting))
+	logger.Debugf(ctx, "[Container] Memory forgetting runner registered")
 	must(container.Provide(service.NewHousekeepingService))
 	must(container.Invoke(startHousekeepingService))
 	logger.Debugf(ctx, "[Container] Knowledge housekeeping runner registered")
@@ -296,6 +357,7 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Invoke(chatpipeline.NewPluginWebFetch))
 	must(container.Invoke(chatpipeline.NewPluginMerge))
 	must(container.Invoke(chatpipeline.NewPluginDataAnalysis))
+	must(container.Invoke(chatpipeline.NewPluginText2SQL))
 	must(container.Invoke(chatpipeline.NewPluginIntoChatMessage))
 	must(container.Invoke(chatpipeline.NewPluginChatCompletion))
 	must(container.Invoke(chatpipeline.NewPluginChatCompletionStream))
@@ -307,6 +369,13 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Invoke(chatpipeline.NewPluginSearchParallel))
 	must(container.Invoke(chatpipeline.NewPluginWikiBoost))
 	must(container.Invoke(chatpipeline.NewMemoryPlugin))
+	must(container.Invoke(chatpipeline.NewPluginMemoryRewrite))
+	must(container.Invoke(chatpipeline.NewPluginGuardrails))
+	must(container.Invoke(chatpipeline.NewPluginKBRoute))
+	must(container.Invoke(chatpipeline.NewPluginQueryVariants))
+	must(container.Invoke(chatpipeline.NewPluginContextWindow))
+	must(container.Invoke(chatpipeline.NewPluginContextPack))
+	must(container.Invoke(chatpipeline.RegisterConfiguredHooks))
 	logger.Debugf(ctx, "[Container] Chat pipeline plugins registered")
 
 	// HTTP handlers layer
@@ -320,13 +389,27 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(handler.NewChunkHandler))
 	must(container.Provide(handler.NewFAQHandler))
 	must(container.Provide(handler.NewTagHandler))
+	must(container.Provide(handler.NewGlossaryHandler))
+	must(container.Provide(handler.NewGraphCommunityHandler))
+	must(container.Provide(handler.NewDuplicateHandler))
+	must(container.Provide(handler.NewAutoTagHandler))
+	must(container.Provide(handler.NewTagAccessHandler))
+	must(container.Provide(handler.NewAPIKeyHandler))
+	must(container.Provide(handler.NewRetentionHandler))
+	must(container.Provide(handler.NewIndexMigrationHandler))
 	must(container.Provide(session.NewHandler))
 	must(container.Provide(handler.NewMessageHandler))
+	must(container.Provide(handler.NewFeedbackHandler))
 	must(container.Provide(handler.NewModelHandler))
 	must(container.Provide(handler.NewEvaluationHandler))
+	must(container.Provide(handler.NewRetrievalEvalHandler))
+	must(container.Provide(handler.NewExperimentHandler))
+	must(container.Provide(handler.NewAnalyticsHandler))
+	must(container.Provide(handler.NewKnowledgeGapHandler))
 	must(container.Provide(handler.NewInitializationHandler))
 	must(container.Provide(handler.NewAuthHandler))
 	must(container.Provide(handler.NewSystemHandler))
+	must(container.Provide(handler.NewHealthHandler))
 	must(container.Provide(handler.NewMCPServiceHandler))
 	must(container.Provide(handler.NewMCPCredentialsHandler))
 	must(container.Provide(handler.NewMCPOAuthHandler))
@@ -335,9 +418,12 @@ func BuildContainer(container *dig.Container) *dig.Container {
 	must(container.Provide(handler.NewDataSourceCredentialsHandler))
 	must(container.Provide(handler.NewWebSearchHandler))
 	must(container.Provide(handler.NewWebSearchProviderHandler))
+	must(container.Provide(handler.NewSQLConnectionHandler))
+	must(container.Provide(handler.NewTokenUsageHandler))
 	must(container.Provide(handler.NewVectorStoreHandler))
 	must(container.Provide(handler.NewCustomAgentHandler))
 	must(container.Provide(handler.NewUserResourceFavoriteHandler))
+	must(container.Provide(handler.NewMemoryHandler))
 	must(container.Provide(service.NewSkillService))
 	must(container.Provide(handler.NewSkillHandler))
 	must(container.Provide(handler.NewOrganizationHandler))
@@ -427,6 +513,33 @@ func initLangfuse() (*langfuse.Manager, error) {
 	return langfuse.Init(cfg)
 }
 
+// initTelemetry installs the OpenTelemetry tracer provider. The OTLP
+// exporter reads the standard OTEL_EXPORTER_OTLP_* variables (see
+// docs/OpenTelemetry链路追踪.md); without an endpoint tracing stays off.
+func initTelemetry() (*telemetry.Provider, error) {
+	ctx := context.Background()
+	cfg := telemetry.LoadConfigFromEnv()
+	if !cfg.Enabled {
+		return telemetry.Init(ctx, cfg, nil)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	var (
+		exporter sdktrace.SpanExporter
+		err      error
+	)
+	if cfg.Protocol == telemetry.ProtocolHTTP {
+		exporter, err = otlptracehttp.New(ctx)
+	} else {
+		exporter, err = otlptracegrpc.New(ctx)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create OTLP trace exporter: %w", err)
+	}
+	return telemetry.Init(ctx, cfg, exporter)
+}
+
 func initRedisClient() (*redis.Client, error) {
 	redisAddr := os.Getenv("REDIS_ADDR")
 	if redisAddr == "" {
@@ -685,6 +798,15 @@ func initFileService(cfg *config.Config) (interfaces.FileService, error) {
 	if storageType == "" {
 		storageType = "local"
 	}
+	svc, err := newFileService(cfg, storageType)
+	if err != nil {
+		return nil, err
+	}
+	return file.WithTracing(svc, storageType), nil
+}
+
+// newFileService creates the file service of storageType.
+func newFileService(cfg *config.Config, storageType string) (interfaces.FileService, error) {
 	switch storageType {
 	case "minio":
 		if os.Getenv("MINIO_ENDPOINT") == "" ||
@@ -754,6 +876,33 @@ func initFileService(cfg *config.Config) (interfaces.FileService, error) {
 			os.Getenv("S3_BUCKET_NAME"),
 			os.Getenv("S3_REGION"),
 			pathPrefix,
+			strings.EqualFold(os.Getenv("S3_FORCE_PATH_STYLE"), "true"),
+		)
+	case "gcs":
+		if os.Getenv("GCS_BUCKET_NAME") == "" {
+			return nil, fmt.Errorf("missing GCS configuration")
+		}
+		// Without a key file the client falls back to Application Default
+		// Credentials, e.g. workload identity on GKE.
+		var credentialsJSON string
+		if credentialsFile := os.Getenv("GCS_CREDENTIALS_FILE"); credentialsFile != "" {
+			data, err := os.ReadFile(credentialsFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read GCS credentials file: %w", err)
+			}
+			credentialsJSON = string(data)
+		}
+		pathPrefix := os.Getenv("GCS_PATH_PREFIX")
+		if pathPrefix == "" {
+			pathPrefix = "weknora/"
+		}
+		return file.NewGCSFileService(
+			os.Getenv("GCS_BUCKET_NAME"),
+			os.Getenv("GCS_PROJECT_ID"),
+			os.Getenv("GCS_LOCATION"),
+			credentialsJSON,
+			os.Getenv("GCS_SERVICE_ACCOUNT_EMAIL"),
+			pathPrefix,
 		)
 	case "obs":
 		if os.Getenv("OBS_ENDPOINT") == "" ||
@@ -817,13 +966,17 @@ func initFileService(cfg *config.Config) (interfaces.FileService, error) {
 // Parameters:
 //   - db: Database connection
 //   - cfg: Application configuration
+//   - rdb: Redis client for the optional shared retrieval cache (nil in Lite mode)
 //
 // Returns:
 //   - Configured retrieval engine registry
 //   - Error if initialization fails
 func initRetrieveEngineRegistry(
-	db *gorm.DB, cfg *config.Config, auditSvc interfaces.AuditLogService,
+	db *gorm.DB, cfg *config.Config, auditSvc interfaces.AuditLogService, rdb *redis.Client,
 ) (interfaces.RetrieveEngineRegistry, error) {
+	// Engines pick up the retrieval cache when they are built, so it must
+	// know about Redis first.
+	retriever.ConfigureRetrieveCache(rdb)
 	registry := retriever.NewRetrieveEngineRegistry()
 	retrieveDriver := strings.Split(os.Getenv("RETRIEVE_DRIVER"), ",")
 	log := logger.GetLogger(context.Background())
@@ -1211,6 +1364,19 @@ func registerLangfuseCleanup(mgr *langfuse.Manager, cleaner interfaces.ResourceC
 	})
 }
 
+// registerTelemetryCleanup flushes the spans still queued on shutdown, with
+// the same 5-second budget as the Langfuse cleanup.
+func registerTelemetryCleanup(provider *telemetry.Provider, cleaner interfaces.ResourceCleaner) {
+	if provider == nil {
+		return
+	}
+	cleaner.RegisterWithName("OpenTelemetry", func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return provider.Shutdown(ctx)
+	})
+}
+
 // initDocReaderClient initializes the DocumentReader client (lightweight API).
 func initDocReaderClient(cfg *config.Config) (interfaces.DocumentReader, error) {
 	addr := strings.TrimSpace(os.Getenv("DOCREADER_ADDR"))
@@ -1287,6 +1453,42 @@ func initNeo4jClient() (neo4j.Driver, error) {
 	return nil, fmt.Errorf("failed to connect to Neo4j after %d attempts: %w", maxRetries, err)
 }
 
+// initGraphRepository selects where knowledge base graphs are stored from
+// GRAPH_DRIVER: "neo4j" (the default) needs NEO4J_ENABLE, "database" keeps
+// them in the application database.
+func initGraphRepository(driver neo4j.Driver, db *gorm.DB) interfaces.RetrieveGraphRepository {
+	ctx := context.Background()
+	switch graphDriver := strings.ToLower(strings.TrimSpace(os.Getenv("GRAPH_DRIVER"))); graphDriver {
+	case "database":
+		logger.Infof(ctx, "Knowledge graph stored in the %s database", db.Dialector.Name())
+		return graphRelationalRepo.NewGraphRepository(db)
+	case "", "neo4j":
+	default:
+		logger.Warnf(ctx, "Unknown GRAPH_DRIVER %q, falling back to neo4j", graphDriver)
+	}
+	return neo4jRepo.NewNeo4jRepository(driver)
+}
+
+// initMemoryRepository selects where conversation memory is stored from
+// MEMORY_DRIVER: "neo4j" (the default) keeps the memory graph in Neo4j and
+// needs NEO4J_ENABLE, "database" keeps it in the application database so
+// minimal deployments get memory without running Neo4j.
+func initMemoryRepository(driver neo4j.Driver, db *gorm.DB) interfaces.MemoryRepository {
+	ctx := context.Background()
+	switch memoryDriver := strings.ToLower(strings.TrimSpace(os.Getenv("MEMORY_DRIVER"))); memoryDriver {
+	case "database":
+		logger.Infof(ctx, "Memory graph stored in the %s database", db.Dialector.Name())
+		return memoryRelationalRepo.NewMemoryRepository(db)
+	case "", "neo4j":
+	default:
+		logger.Warnf(ctx, "Unknown MEMORY_DRIVER %q, falling back to neo4j", memoryDriver)
+	}
+	if err := memoryRepo.MigrateTenantScope(ctx, driver); err != nil {
+		logger.Warnf(ctx, "Failed to partition the memory graph by tenant: %v", err)
+	}
+	return memoryRepo.NewMemoryRepository(driver)
+}
+
 func NewDuckDB() (*sql.DB, error) {
 	sqlDB, err := sql.Open("duckdb", ":memory:")
 	if err != nil {
@@ -1365,15 +1567,26 @@ func initConnectorRegistry() (*datasource.ConnectorRegistry, error) {
 	if err := registry.Register(notionConnector.NewConnector()); err != nil {
 		errs = errors.Join(errs, fmt.Errorf("register notion connector: %w", err))
 	}
+	if err := registry.Register(confluenceConnector.NewConnector()); err != nil {
+		errs = errors.Join(errs, fmt.Errorf("register confluence connector: %w", err))
+	}
 	if err := registry.Register(yuqueConnector.NewConnector()); err != nil {
 		errs = errors.Join(errs, fmt.Errorf("register yuque connector: %w", err))
 	}
 	if err := registry.Register(rssConnector.NewConnector()); err != nil {
 		errs = errors.Join(errs, fmt.Errorf("register rss connector: %w", err))
 	}
+	if err := registry.Register(imapConnector.NewConnector()); err != nil {
+		errs = errors.Join(errs, fmt.Errorf("register imap connector: %w", err))
+	}
+	if err := registry.Register(gitConnector.NewConnector()); err != nil {
+		errs = errors.Join(errs, fmt.Errorf("register git connector: %w", err))
+	}
+	if err := registry.Register(bucketConnector.NewConnector()); err != nil {
+		errs = errors.Join(errs, fmt.Errorf("register bucket connector: %w", err))
+	}
 
 	// Future connectors will be registered here:
-	// if err := registry.Register(confluenceConnector.NewConnector()); err != nil { ... }
 	// if err := registry.Register(githubConnector.NewConnector()); err != nil { ... }
 
 	if errs != nil {
@@ -1412,6 +1625,73 @@ func startHousekeepingService(svc *service.HousekeepingService, cleaner interfac
 	})
 }
 
+// startTempFileCleanup spins up the hourly sweep of expired temp files and
+// stops it on shutdown, like startAuditLogRetention.
+func startTempFileCleanup(
+	runner *service.TempFileCleanupRunner, cleaner interfaces.ResourceCleaner,
+) {
+	runner.Start(context.Background())
+	cleaner.RegisterWithName("TempFileCleanupRunner", func() error {
+		runner.Stop()
+		return nil
+	})
+}
+
+// startKBSnapshotScheduler spins up the periodic check for due knowledge
+// base snapshot policies and stops it on shutdown, like startTempFileCleanup.
+func startKBSnapshotScheduler(
+	scheduler *service.KBSnapshotScheduler, cleaner interfaces.ResourceCleaner,
+) {
+	scheduler.Start(context.Background())
+	cleaner.RegisterWithName("KBSnapshotScheduler", func() error {
+		scheduler.Stop()
+		return nil
+	})
+}
+
+// startRetentionScheduler spins up the periodic check for due retention
+// policies and stops it on shutdown, like startKBSnapshotScheduler.
+func startRetentionScheduler(
+	scheduler *service.RetentionScheduler, cleaner interfaces.ResourceCleaner,
+) {
+	scheduler.Start(context.Background())
+	cleaner.RegisterWithName("RetentionScheduler", func() error {
+		scheduler.Stop()
+		return nil
+	})
+}
+
+// startMemoryConsolidation spins up the periodic merge of duplicate memory
+// graph entities and stops it on shutdown. The runner stays dormant when
+// the memory graph is unavailable.
+func startMemoryConsolidation(
+	runner *memoryService.ConsolidationRunner, cleaner interfaces.ResourceCleaner,
+) {
+	runner.Start(context.Background())
+	cleaner.RegisterWithName("MemoryConsolidationRunner", func() error {
+		runner.Stop()
+		return nil
+	})
+}
+
+// startMemoryForgetting spins up the daily memory forgetting sweep and stops
+// it on shutdown. The runner stays dormant unless MEMORY_FORGET_POLICY is set.
+func startMemoryForgetting(
+	runner *memoryService.ForgettingRunner, cleaner interfaces.ResourceCleaner,
+) {
+	runner.Start(context.Background())
+	cleaner.RegisterWithName("MemoryForgettingRunner", func() error {
+		runner.Stop()
+		return nil
+	})
+}
+
+// registerTokenUsageRecorder makes the models account the token usage of
+// every chat and embedding call and check the tenant token budgets.
+func registerTokenUsageRecorder(svc interfaces.TokenUsageService) {
+	usage.SetRecorder(svc)
+}
+
 // startAuditLogRetention spins up the daily audit_logs purge sweep
 // and registers shutdown cleanup. Mirrors the data-source-scheduler
 // pattern: container init kicks the goroutine, ResourceCleaner stops
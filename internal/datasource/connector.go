@@ -2,6 +2,7 @@ package datasource
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/Tencent/WeKnora/internal/types"
 )
@@ -51,6 +52,17 @@ type Connector interface {
 	FetchIncremental(ctx context.Context, config *types.DataSourceConfig, cursor *types.SyncCursor) ([]types.FetchedItem, *types.SyncCursor, error)
 }
 
+// WebhookConnector is implemented by connectors whose source can push
+// change notifications (e.g. a git push webhook). The webhook endpoint of a
+// data source is public: VerifyWebhook must authenticate the delivery.
+type WebhookConnector interface {
+	// VerifyWebhook authenticates a webhook delivery against the data
+	// source's configuration and reports whether it announces changes that
+	// should trigger a sync (e.g. a push to the synced branch, not a ping).
+	// It returns ErrWebhookUnauthorized when the delivery is not authentic.
+	VerifyWebhook(config *types.DataSourceConfig, header http.Header, body []byte) (bool, error)
+}
+
 // ConnectorRegistry manages the registration and lookup of available connectors
 type ConnectorRegistry struct {
 	connectors map[string]Connector
@@ -121,7 +133,7 @@ var ConnectorMetadataRegistry = map[string]ConnectorMetadata{
 		Description:  "Sync pages and databases from Notion",
 		Priority:     1,
 		AuthType:     "api_key",
-		Capabilities: []string{"incremental"},
+		Capabilities: []string{"incremental", "deletion_sync"},
 	},
 	types.ConnectorTypeConfluence: {
 		Type:         types.ConnectorTypeConfluence,
@@ -129,7 +141,7 @@ var ConnectorMetadataRegistry = map[string]ConnectorMetadata{
 		Description:  "Sync spaces and pages from Atlassian Confluence",
 		Priority:     2,
 		AuthType:     "api_key",
-		Capabilities: []string{"incremental"},
+		Capabilities: []string{"incremental", "deletion_sync"},
 	},
 	types.ConnectorTypeYuque: {
 		Type:         types.ConnectorTypeYuque,
@@ -190,10 +202,10 @@ var ConnectorMetadataRegistry = map[string]ConnectorMetadata{
 	types.ConnectorTypeIMAP: {
 		Type:         types.ConnectorTypeIMAP,
 		Name:         "Email (IMAP)",
-		Description:  "Sync email content from IMAP servers",
+		Description:  "Sync email from IMAP folders as Markdown with sender and date metadata",
 		Priority:     11,
 		AuthType:     "password",
-		Capabilities: []string{},
+		Capabilities: []string{"incremental"},
 	},
 	types.ConnectorTypeRSS: {
 		Type:         types.ConnectorTypeRSS,
@@ -203,6 +215,22 @@ var ConnectorMetadataRegistry = map[string]ConnectorMetadata{
 		AuthType:     "custom",
 		Capabilities: []string{"incremental"},
 	},
+	types.ConnectorTypeGit: {
+		Type:         types.ConnectorTypeGit,
+		Name:         "Git Repository",
+		Description:  "Sync source code and Markdown docs from a Git repository, re-indexed on push",
+		Priority:     13,
+		AuthType:     "token",
+		Capabilities: []string{"incremental", "webhook", "deletion_sync"},
+	},
+	types.ConnectorTypeBucket: {
+		Type:         types.ConnectorTypeBucket,
+		Name:         "Object Storage",
+		Description:  "Watch an S3 / TOS / COS bucket prefix and ingest new, changed and deleted documents",
+		Priority:     14,
+		AuthType:     "api_key",
+		Capabilities: []string{"incremental", "webhook", "deletion_sync"},
+	},
 }
 
 // ListAvailableConnectors returns all available connector metadata
@@ -0,0 +1,155 @@
+package bucket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// object is a listed object of the bucket.
+type object struct {
+	Key          string
+	ETag         string
+	Size         int64
+	LastModified time.Time
+}
+
+// client wraps the S3-compatible API of the configured bucket.
+type client struct {
+	bucket string
+	s3     *s3.Client
+}
+
+// newClient constructs a client for cfg. Custom endpoints are checked
+// against the SSRF policy first.
+func newClient(ctx context.Context, cfg *Config) (*client, error) {
+	endpoint := cfg.endpointURL()
+	if cfg.Endpoint != "" {
+		if err := utils.ValidateURLForSSRF(endpoint); err != nil {
+			return nil, fmt.Errorf("%w: endpoint rejected: %v", datasource.ErrInvalidConfig, err)
+		}
+	}
+	awsCfg, err := config.LoadDefaultConfig(ctx,
+		config.WithRegion(cfg.region()),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			strings.TrimSpace(cfg.AccessKeyID), strings.TrimSpace(cfg.SecretAccessKey), "",
+		)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("load s3 config: %w", err)
+	}
+	cli := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+		o.UsePathStyle = cfg.usePathStyle()
+	})
+	return &client{bucket: cfg.Bucket, s3: cli}, nil
+}
+
+// Ping verifies the credentials and the bucket by listing a single key.
+func (c *client) Ping(ctx context.Context, prefix string) error {
+	_, err := c.s3.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(c.bucket),
+		Prefix:  aws.String(prefix),
+		MaxKeys: aws.Int32(1),
+	})
+	return wrapError(err)
+}
+
+// ListDirs returns the first-level common prefixes under prefix, relative
+// to it and without the trailing "/".
+func (c *client) ListDirs(ctx context.Context, prefix string) ([]string, error) {
+	var dirs []string
+	p := s3.NewListObjectsV2Paginator(c.s3, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(c.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+		for _, cp := range page.CommonPrefixes {
+			dir := strings.TrimSuffix(relativePath(prefix, aws.ToString(cp.Prefix)), "/")
+			if dir != "" {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs, nil
+}
+
+// ListObjects returns every object under prefix.
+func (c *client) ListObjects(ctx context.Context, prefix string) ([]object, error) {
+	var out []object
+	p := s3.NewListObjectsV2Paginator(c.s3, &s3.ListObjectsV2Input{
+		Bucket: aws.String(c.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for p.HasMorePages() {
+		page, err := p.NextPage(ctx)
+		if err != nil {
+			return nil, wrapError(err)
+		}
+		for _, o := range page.Contents {
+			out = append(out, object{
+				Key:          aws.ToString(o.Key),
+				ETag:         strings.Trim(aws.ToString(o.ETag), `"`),
+				Size:         aws.ToInt64(o.Size),
+				LastModified: aws.ToTime(o.LastModified),
+			})
+		}
+	}
+	return out, nil
+}
+
+// GetObject downloads the content of key, reading at most limit bytes.
+func (c *client) GetObject(ctx context.Context, key string, limit int64) ([]byte, string, error) {
+	resp, err := c.s3.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(c.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, "", wrapError(err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("read object: %w", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, "", fmt.Errorf("object exceeds %d bytes", limit)
+	}
+	return data, aws.ToString(resp.ContentType), nil
+}
+
+// wrapError maps authentication and authorization failures to
+// ErrInvalidCredentials so DataSourceService can tell bad keys from
+// transient failures.
+func wrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var respErr *awshttp.ResponseError
+	if errors.As(err, &respErr) {
+		switch respErr.HTTPStatusCode() {
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return fmt.Errorf("%w: %v", datasource.ErrInvalidCredentials, err)
+		}
+	}
+	return err
+}
@@ -0,0 +1,234 @@
+package bucket
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+// TestMain whitelists loopback for SSRF so the httptest S3 server
+// (127.0.0.1) is reachable. Production keeps the default strict SSRF policy.
+func TestMain(m *testing.M) {
+	_ = os.Setenv("SSRF_WHITELIST", "127.0.0.1,::1")
+	utils.ResetSSRFWhitelistForTest()
+	os.Exit(m.Run())
+}
+
+// fakeS3 emulates the path-style ListObjectsV2 and GetObject endpoints of
+// a single bucket.
+type fakeS3 struct {
+	server *httptest.Server
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string]string // key → content
+	failGet map[string]bool   // keys whose GetObject returns 500
+	denied  bool              // every request returns 403
+	gets    []string          // GetObject history
+}
+
+type listBucketResult struct {
+	XMLName        xml.Name       `xml:"ListBucketResult"`
+	Name           string         `xml:"Name"`
+	Prefix         string         `xml:"Prefix"`
+	KeyCount       int            `xml:"KeyCount"`
+	IsTruncated    bool           `xml:"IsTruncated"`
+	Contents       []listContent  `xml:"Contents"`
+	CommonPrefixes []commonPrefix `xml:"CommonPrefixes"`
+}
+
+type listContent struct {
+	Key          string `xml:"Key"`
+	ETag         string `xml:"ETag"`
+	Size         int    `xml:"Size"`
+	LastModified string `xml:"LastModified"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+func newFakeS3() *fakeS3 {
+	f := &fakeS3{bucket: "docs", objects: make(map[string]string), failGet: make(map[string]bool)}
+	f.server = httptest.NewServer(http.HandlerFunc(f.serve))
+	return f
+}
+
+func (f *fakeS3) Close() { f.server.Close() }
+
+func (f *fakeS3) put(key, content string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.objects[key] = content
+}
+
+func (f *fakeS3) remove(key string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects, key)
+}
+
+func etagOf(content string) string {
+	sum := md5.Sum([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func (f *fakeS3) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.denied {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`))
+		return
+	}
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<Error><Code>NoSuchBucket</Code></Error>`))
+		return
+	}
+	if key == "" {
+		f.list(w, r.URL.Query().Get("prefix"), r.URL.Query().Get("delimiter"))
+		return
+	}
+	f.gets = append(f.gets, key)
+	content, ok := f.objects[key]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+		return
+	}
+	if f.failGet[key] {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("ETag", `"`+etagOf(content)+`"`)
+	_, _ = w.Write([]byte(content))
+}
+
+func (f *fakeS3) list(w http.ResponseWriter, prefix, delimiter string) {
+	keys := make([]string, 0, len(f.objects))
+	for k := range f.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	res := listBucketResult{Name: f.bucket, Prefix: prefix}
+	seen := make(map[string]bool)
+	for _, k := range keys {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		if delimiter != "" {
+			if i := strings.Index(k[len(prefix):], delimiter); i >= 0 {
+				cp := k[:len(prefix)+i+1]
+				if !seen[cp] {
+					seen[cp] = true
+					res.CommonPrefixes = append(res.CommonPrefixes, commonPrefix{Prefix: cp})
+				}
+				continue
+			}
+		}
+		res.Contents = append(res.Contents, listContent{
+			Key:          k,
+			ETag:         `"` + etagOf(f.objects[k]) + `"`,
+			Size:         len(f.objects[k]),
+			LastModified: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC).Format(time.RFC3339),
+		})
+	}
+	res.KeyCount = len(res.Contents)
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(res)
+}
+
+func (f *fakeS3) cfg() *Config {
+	return &Config{
+		Provider:        ProviderS3,
+		Endpoint:        f.server.URL,
+		Bucket:          f.bucket,
+		AccessKeyID:     "ak",
+		SecretAccessKey: "sk",
+	}
+}
+
+func TestClient_ListObjectsAndDirs(t *testing.T) {
+	f := newFakeS3()
+	defer f.Close()
+	f.put("inbox/a.pdf", "a")
+	f.put("inbox/hr/b.docx", "bb")
+	f.put("inbox/legal/c.md", "ccc")
+	f.put("other/d.md", "d")
+
+	cli, err := newClient(context.Background(), f.cfg())
+	if err != nil {
+		t.Fatalf("newClient: %v", err)
+	}
+	objects, err := cli.ListObjects(context.Background(), "inbox/")
+	if err != nil {
+		t.Fatalf("ListObjects: %v", err)
+	}
+	if len(objects) != 3 || objects[1].Key != "inbox/hr/b.docx" || objects[1].Size != 2 || objects[1].ETag != etagOf("bb") {
+		t.Errorf("objects = %+v", objects)
+	}
+
+	dirs, err := cli.ListDirs(context.Background(), "inbox/")
+	if err != nil {
+		t.Fatalf("ListDirs: %v", err)
+	}
+	if strings.Join(dirs, ",") != "hr,legal" {
+		t.Errorf("dirs = %v, want hr, legal", dirs)
+	}
+}
+
+func TestClient_403WrapsInvalidCredentials(t *testing.T) {
+	f := newFakeS3()
+	defer f.Close()
+	f.denied = true
+
+	cli, err := newClient(context.Background(), f.cfg())
+	if err != nil {
+		t.Fatalf("newClient: %v", err)
+	}
+	if err := cli.Ping(context.Background(), ""); !errors.Is(err, datasource.ErrInvalidCredentials) {
+		t.Errorf("403 should wrap ErrInvalidCredentials, got: %v", err)
+	}
+}
+
+func TestClient_GetObject_SizeLimit(t *testing.T) {
+	f := newFakeS3()
+	defer f.Close()
+	f.put("big.txt", "0123456789")
+
+	cli, err := newClient(context.Background(), f.cfg())
+	if err != nil {
+		t.Fatalf("newClient: %v", err)
+	}
+	if _, _, err := cli.GetObject(context.Background(), "big.txt", 5); err == nil {
+		t.Error("GetObject over the limit should fail")
+	}
+	data, _, err := cli.GetObject(context.Background(), "big.txt", 10)
+	if err != nil || string(data) != "0123456789" {
+		t.Errorf("GetObject = %q, %v", data, err)
+	}
+}
+
+func TestNewClient_RejectsInternalEndpoint(t *testing.T) {
+	cfg := &Config{Provider: ProviderS3, Endpoint: "http://169.254.169.254", Bucket: "b", AccessKeyID: "ak", SecretAccessKey: "sk"}
+	if _, err := newClient(context.Background(), cfg); !errors.Is(err, datasource.ErrInvalidConfig) {
+		t.Errorf("err = %v, want ErrInvalidConfig", err)
+	}
+}
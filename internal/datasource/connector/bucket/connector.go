@@ -0,0 +1,239 @@
+package bucket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+// Compile-time proof that *Connector satisfies the connector interfaces.
+var (
+	_ datasource.Connector        = (*Connector)(nil)
+	_ datasource.WebhookConnector = (*Connector)(nil)
+)
+
+// Connector implements datasource.Connector for S3-compatible object storage.
+type Connector struct{}
+
+// NewConnector creates a new object storage connector.
+func NewConnector() *Connector { return &Connector{} }
+
+// Type returns the connector type identifier.
+func (c *Connector) Type() string { return types.ConnectorTypeBucket }
+
+// Validate verifies the keys and the bucket by listing a key under the prefix.
+func (c *Connector) Validate(ctx context.Context, config *types.DataSourceConfig) error {
+	cfg, err := parseConfig(config)
+	if err != nil {
+		return err
+	}
+	cli, err := newClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	if err := cli.Ping(ctx, cfg.Prefix); err != nil {
+		return fmt.Errorf("bucket connection failed: %w", err)
+	}
+	return nil
+}
+
+// ResolveResourceAncestors has nothing to do: directories are listed one
+// level deep, so a selection has no ancestors to reveal.
+func (c *Connector) ResolveResourceAncestors(
+	ctx context.Context, config *types.DataSourceConfig, resourceIDs []string,
+) ([]string, error) {
+	return []string{}, nil
+}
+
+// ListResources returns the first-level directories under the prefix.
+// Resource IDs are directory names relative to the prefix.
+func (c *Connector) ListResources(
+	ctx context.Context, config *types.DataSourceConfig, parentID string,
+) ([]types.Resource, error) {
+	if parentID != "" {
+		return []types.Resource{}, nil
+	}
+	cfg, err := parseConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := newClient(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	dirs, err := cli.ListDirs(ctx, cfg.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("list directories: %w", err)
+	}
+
+	out := make([]types.Resource, 0, len(dirs))
+	for _, dir := range dirs {
+		out = append(out, types.Resource{
+			ExternalID:  dir,
+			Name:        dir,
+			Type:        "directory",
+			Description: cfg.Bucket + "/" + cfg.Prefix + dir + "/",
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ExternalID < out[j].ExternalID })
+	return out, nil
+}
+
+// FetchAll downloads every document under the prefix that lies in one of
+// the selected directories (all of them when resourceIDs is empty).
+func (c *Connector) FetchAll(ctx context.Context, config *types.DataSourceConfig, resourceIDs []string) ([]types.FetchedItem, error) {
+	items, _, err := c.walk(ctx, config, resourceIDs, nil)
+	return items, err
+}
+
+// FetchIncremental lists the prefix and compares the ETags with the prior
+// cursor: new and changed objects are downloaded, objects that disappeared
+// are emitted as IsDeleted=true items. A cursor recorded for another
+// endpoint, bucket or prefix is ignored, giving a full sync without
+// deletions.
+func (c *Connector) FetchIncremental(
+	ctx context.Context,
+	config *types.DataSourceConfig,
+	cursor *types.SyncCursor,
+) ([]types.FetchedItem, *types.SyncCursor, error) {
+	prev := &bucketCursor{}
+	if cursor != nil && cursor.ConnectorCursor != nil {
+		b, _ := json.Marshal(cursor.ConnectorCursor)
+		_ = json.Unmarshal(b, prev)
+	}
+
+	items, newCursor, err := c.walk(ctx, config, config.ResourceIDs, prev)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cursorMap := make(map[string]interface{})
+	b, _ := json.Marshal(newCursor)
+	_ = json.Unmarshal(b, &cursorMap)
+	return items, &types.SyncCursor{
+		LastSyncTime:    newCursor.LastSyncTime,
+		ConnectorCursor: cursorMap,
+	}, nil
+}
+
+// walk is the shared implementation for FetchAll / FetchIncremental. prev is
+// nil for a full sync, in which case no cursor is returned.
+func (c *Connector) walk(
+	ctx context.Context,
+	config *types.DataSourceConfig,
+	resourceIDs []string,
+	prev *bucketCursor,
+) ([]types.FetchedItem, *bucketCursor, error) {
+	cfg, err := parseConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	cli, err := newClient(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	objects, err := cli.ListObjects(ctx, cfg.Prefix)
+	if err != nil {
+		return nil, nil, fmt.Errorf("list objects: %w", err)
+	}
+
+	var prevObjects map[string]string
+	if prev != nil && prev.Location == cfg.location() {
+		prevObjects = prev.Objects
+	} else if prev != nil && prev.Location != "" {
+		logger.Infof(ctx, "[Bucket] location changed from %s to %s, running a full sync", prev.Location, cfg.location())
+	}
+
+	maxSize := utils.GetMaxFileSize()
+	newCursor := &bucketCursor{
+		LastSyncTime: time.Now(),
+		Location:     cfg.location(),
+		Objects:      make(map[string]string),
+	}
+	current := make(map[string]bool, len(objects))
+	var out []types.FetchedItem
+	fetched, skipped := 0, 0
+
+	for _, obj := range objects {
+		rel := relativePath(cfg.Prefix, obj.Key)
+		if !isDocument(obj.Key) || !selected(rel, resourceIDs) {
+			continue
+		}
+		if obj.Size > maxSize {
+			skipped++
+			logger.Warnf(ctx, "[Bucket] skipping %s: size %d exceeds %d bytes", obj.Key, obj.Size, maxSize)
+			continue
+		}
+		current[obj.Key] = true
+		if etag, synced := prevObjects[obj.Key]; synced && etag == obj.ETag {
+			newCursor.Objects[obj.Key] = etag
+			continue
+		}
+
+		item, ok := c.fetchObject(ctx, cli, cfg, obj, maxSize)
+		out = append(out, item)
+		fetched++
+		// Failed objects stay out of the cursor so the next sync retries them.
+		if ok {
+			newCursor.Objects[obj.Key] = obj.ETag
+		}
+	}
+
+	for key := range prevObjects {
+		if !current[key] {
+			out = append(out, types.FetchedItem{
+				ExternalID:       itemExternalID(cfg.Bucket, key),
+				IsDeleted:        true,
+				SourceResourceID: topLevelDir(relativePath(cfg.Prefix, key)),
+			})
+		}
+	}
+	logger.Infof(ctx, "[Bucket] %s: objects=%d fetched=%d skipped=%d",
+		cfg.location(), len(current), fetched, skipped)
+
+	if prev == nil {
+		return out, nil, nil
+	}
+	return out, newCursor, nil
+}
+
+// fetchObject downloads one object and converts it into a FetchedItem,
+// reporting whether the download succeeded. Failures produce a placeholder
+// item carrying the error in its metadata.
+func (c *Connector) fetchObject(
+	ctx context.Context, cli *client, cfg *Config, obj object, maxSize int64,
+) (types.FetchedItem, bool) {
+	rel := relativePath(cfg.Prefix, obj.Key)
+	item := types.FetchedItem{
+		ExternalID:       itemExternalID(cfg.Bucket, obj.Key),
+		Title:            rel,
+		FileName:         path.Base(obj.Key),
+		UpdatedAt:        obj.LastModified,
+		SourceResourceID: topLevelDir(rel),
+		Metadata: map[string]string{
+			"channel": types.ChannelBucket,
+			"bucket":  cfg.Bucket,
+			"key":     obj.Key,
+			"etag":    obj.ETag,
+			"size":    strconv.FormatInt(obj.Size, 10),
+		},
+	}
+
+	data, contentType, err := cli.GetObject(ctx, obj.Key, maxSize)
+	if err != nil {
+		item.Metadata["error"] = err.Error()
+		return item, false
+	}
+	item.Content = data
+	item.ContentType = contentType
+	return item, true
+}
@@ -0,0 +1,221 @@
+package bucket
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func makeDSConfig(f *fakeS3, prefix string, resourceIDs []string) *types.DataSourceConfig {
+	return &types.DataSourceConfig{
+		Type: types.ConnectorTypeBucket,
+		Credentials: map[string]interface{}{
+			"access_key_id":     "ak",
+			"secret_access_key": "sk",
+			"webhook_secret":    "hook-secret",
+		},
+		Settings: map[string]interface{}{
+			"provider": ProviderS3,
+			"endpoint": f.server.URL,
+			"bucket":   f.bucket,
+			"prefix":   prefix,
+		},
+		ResourceIDs: resourceIDs,
+	}
+}
+
+// itemsByID indexes fetched items by external ID.
+func itemsByID(items []types.FetchedItem) map[string]types.FetchedItem {
+	out := make(map[string]types.FetchedItem, len(items))
+	for _, it := range items {
+		out[it.ExternalID] = it
+	}
+	return out
+}
+
+func TestConnector_Type(t *testing.T) {
+	if NewConnector().Type() != types.ConnectorTypeBucket {
+		t.Errorf("Type() = %q, want %q", NewConnector().Type(), types.ConnectorTypeBucket)
+	}
+}
+
+func TestConnector_ListResources_Directories(t *testing.T) {
+	f := newFakeS3()
+	defer f.Close()
+	f.put("inbox/readme.md", "r")
+	f.put("inbox/legal/a.pdf", "a")
+	f.put("inbox/hr/b.docx", "b")
+
+	resources, err := NewConnector().ListResources(context.Background(), makeDSConfig(f, "inbox", nil), "")
+	if err != nil {
+		t.Fatalf("ListResources: %v", err)
+	}
+	if len(resources) != 2 || resources[0].ExternalID != "hr" || resources[1].ExternalID != "legal" {
+		t.Fatalf("resources = %+v, want hr, legal", resources)
+	}
+	if resources[0].Type != "directory" || resources[0].Description != "docs/inbox/hr/" {
+		t.Errorf("hr resource = %+v", resources[0])
+	}
+}
+
+func TestConnector_FetchAll_FiltersDocumentsAndSelection(t *testing.T) {
+	f := newFakeS3()
+	defer f.Close()
+	f.put("inbox/readme.md", "# Readme")
+	f.put("inbox/legal/contract.pdf", "%PDF")
+	f.put("inbox/hr/handbook.docx", "docx")
+	f.put("inbox/legal/archive.zip", "zip")
+	f.put("inbox/legal/.trash/old.pdf", "old")
+	f.put("inbox/legal/", "")
+	f.put("outbox/x.md", "x")
+
+	items, err := NewConnector().FetchAll(context.Background(), makeDSConfig(f, "inbox/", nil), []string{"legal"})
+	if err != nil {
+		t.Fatalf("FetchAll: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("items = %+v, want only legal/contract.pdf", items)
+	}
+	it := items[0]
+	if it.ExternalID != "docs/inbox/legal/contract.pdf" || it.Title != "legal/contract.pdf" ||
+		it.FileName != "contract.pdf" || it.SourceResourceID != "legal" || string(it.Content) != "%PDF" {
+		t.Errorf("item = %+v", it)
+	}
+	if it.Metadata["channel"] != types.ChannelBucket || it.Metadata["key"] != "inbox/legal/contract.pdf" ||
+		it.Metadata["etag"] != etagOf("%PDF") {
+		t.Errorf("metadata = %v", it.Metadata)
+	}
+
+	all, err := NewConnector().FetchAll(context.Background(), makeDSConfig(f, "inbox/", nil), nil)
+	if err != nil {
+		t.Fatalf("FetchAll: %v", err)
+	}
+	if len(all) != 3 {
+		t.Errorf("items = %d, want 3 documents under the whole prefix", len(all))
+	}
+}
+
+func TestConnector_FetchIncremental_ETagDiff(t *testing.T) {
+	f := newFakeS3()
+	defer f.Close()
+	f.put("inbox/a.md", "a1")
+	f.put("inbox/b.md", "b1")
+	f.put("inbox/c.md", "c1")
+	conn := NewConnector()
+	config := makeDSConfig(f, "inbox/", nil)
+
+	items, cursor, err := conn.FetchIncremental(context.Background(), config, nil)
+	if err != nil {
+		t.Fatalf("first FetchIncremental: %v", err)
+	}
+	if len(items) != 3 {
+		t.Fatalf("first sync items = %d, want 3", len(items))
+	}
+
+	f.put("inbox/a.md", "a2")
+	f.remove("inbox/b.md")
+	f.put("inbox/d.md", "d1")
+	f.gets = nil
+
+	items, cursor, err = conn.FetchIncremental(context.Background(), config, cursor)
+	if err != nil {
+		t.Fatalf("second FetchIncremental: %v", err)
+	}
+	got := itemsByID(items)
+	if len(items) != 3 || string(got["docs/inbox/a.md"].Content) != "a2" ||
+		!got["docs/inbox/b.md"].IsDeleted || string(got["docs/inbox/d.md"].Content) != "d1" {
+		t.Errorf("items = %+v, want a changed, b deleted, d new", items)
+	}
+	if len(f.gets) != 2 {
+		t.Errorf("downloads = %v, want only a.md and d.md", f.gets)
+	}
+	objects := cursor.ConnectorCursor["objects"].(map[string]interface{})
+	if len(objects) != 3 || objects["inbox/a.md"] != etagOf("a2") {
+		t.Errorf("cursor objects = %v", objects)
+	}
+}
+
+func TestConnector_FetchIncremental_FailedObjectRetriedNextSync(t *testing.T) {
+	f := newFakeS3()
+	defer f.Close()
+	f.put("inbox/a.md", "a1")
+	f.failGet["inbox/a.md"] = true
+	conn := NewConnector()
+	config := makeDSConfig(f, "inbox/", nil)
+
+	items, cursor, err := conn.FetchIncremental(context.Background(), config, nil)
+	if err != nil {
+		t.Fatalf("FetchIncremental: %v", err)
+	}
+	if len(items) != 1 || items[0].Metadata["error"] == "" {
+		t.Fatalf("items = %+v, want one placeholder with error", items)
+	}
+	if objects := cursor.ConnectorCursor["objects"].(map[string]interface{}); len(objects) != 0 {
+		t.Errorf("failed object should stay out of the cursor, got %v", objects)
+	}
+
+	delete(f.failGet, "inbox/a.md")
+	items, _, err = conn.FetchIncremental(context.Background(), config, cursor)
+	if err != nil || len(items) != 1 || string(items[0].Content) != "a1" {
+		t.Errorf("retry items = %+v, %v", items, err)
+	}
+}
+
+func TestConnector_FetchIncremental_LocationChangeSkipsDeletions(t *testing.T) {
+	f := newFakeS3()
+	defer f.Close()
+	f.put("inbox/a.md", "a")
+	f.put("archive/b.md", "b")
+	conn := NewConnector()
+
+	_, cursor, err := conn.FetchIncremental(context.Background(), makeDSConfig(f, "inbox/", nil), nil)
+	if err != nil {
+		t.Fatalf("FetchIncremental: %v", err)
+	}
+	items, _, err := conn.FetchIncremental(context.Background(), makeDSConfig(f, "archive/", nil), cursor)
+	if err != nil {
+		t.Fatalf("FetchIncremental: %v", err)
+	}
+	if len(items) != 1 || items[0].ExternalID != "docs/archive/b.md" || items[0].IsDeleted {
+		t.Errorf("items = %+v, want only archive/b.md without deletions", items)
+	}
+}
+
+func TestConnector_VerifyWebhook(t *testing.T) {
+	f := newFakeS3()
+	defer f.Close()
+	config := makeDSConfig(f, "inbox/", nil)
+	bearer := http.Header{"Authorization": []string{"Bearer hook-secret"}}
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		body    string
+		want    bool
+		wantErr bool
+	}{
+		{"missing token", http.Header{}, `{}`, false, true},
+		{"wrong token", http.Header{"X-Webhook-Token": []string{"nope"}}, `{}`, false, true},
+		{"object under prefix", bearer,
+			`{"Records":[{"eventName":"ObjectCreated:Put","s3":{"object":{"key":"inbox/new+report.pdf"}}}]}`, true, false},
+		{"object outside prefix", http.Header{"X-Webhook-Token": []string{"hook-secret"}},
+			`{"Records":[{"eventName":"ObjectRemoved:Delete","s3":{"object":{"key":"outbox/x.pdf"}}}]}`, false, false},
+		{"cos record", bearer,
+			`{"Records":[{"cos":{"cosObject":{"key":"inbox/a.md"}}}]}`, true, false},
+		{"s3 test event", bearer, `{"Service":"Amazon S3","Event":"s3:TestEvent"}`, false, false},
+		{"unknown payload", bearer, `{"events":[]}`, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewConnector().VerifyWebhook(config, tt.header, []byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("VerifyWebhook = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
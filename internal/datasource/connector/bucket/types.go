@@ -0,0 +1,243 @@
+// Package bucket implements the object storage data source connector for
+// WeKnora.
+//
+// It watches a bucket (optionally under a key prefix) on any S3-compatible
+// object storage, such as AWS S3, Volcengine TOS or Tencent Cloud COS, and
+// ingests the documents dropped into it, one knowledge entry per object.
+// The first-level "directories" under the prefix are the selectable
+// resources; an empty selection syncs the whole prefix.
+//
+// Capabilities:
+//   - Incremental: the cursor holds the ETag of every synced object. The
+//     next sync lists the prefix again and only re-ingests new objects and
+//     objects whose ETag changed; objects that disappeared are emitted as
+//     deletions.
+//   - Webhook: bucket event notifications (S3 event JSON) authenticated by
+//     the webhook secret trigger a sync (see VerifyWebhook).
+//
+// Custom endpoints are checked against the SSRF policy.
+package bucket
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Storage providers with a built-in S3-compatible endpoint.
+const (
+	ProviderS3  = "s3"
+	ProviderTOS = "tos"
+	ProviderCOS = "cos"
+)
+
+// documentExtensions are the object extensions ingested from a bucket.
+var documentExtensions = map[string]bool{
+	".pdf": true, ".doc": true, ".docx": true, ".ppt": true, ".pptx": true,
+	".xls": true, ".xlsx": true, ".csv": true, ".txt": true, ".md": true,
+	".markdown": true, ".json": true, ".epub": true, ".mhtml": true,
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true,
+}
+
+// Config holds the object storage configuration.
+//
+// Provider, Endpoint, Region, Bucket, Prefix and ForcePathStyle are stored
+// in DataSourceConfig.Settings (non-secret, editable without replacing
+// credentials); the keys and WebhookSecret live in Credentials so they are
+// encrypted at rest.
+type Config struct {
+	// Provider selects the default endpoint: s3 (AWS, or any S3-compatible
+	// service with Endpoint set), tos or cos.
+	Provider string `json:"provider,omitempty"`
+	// Endpoint overrides the provider endpoint, e.g. a MinIO URL.
+	Endpoint string `json:"endpoint,omitempty"`
+	Region   string `json:"region,omitempty"`
+	Bucket   string `json:"bucket"`
+	// Prefix restricts the sync to keys under it, e.g. "inbox/".
+	Prefix string `json:"prefix,omitempty"`
+	// ForcePathStyle addresses the bucket as endpoint/bucket instead of
+	// bucket.endpoint.
+	ForcePathStyle bool `json:"force_path_style,omitempty"`
+
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"`
+	// WebhookSecret authenticates bucket event notifications.
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+}
+
+// settingKeys are the configuration keys read from Settings.
+var settingKeys = []string{"provider", "endpoint", "region", "bucket", "prefix", "force_path_style"}
+
+// parseConfig extracts and validates the object storage configuration.
+func parseConfig(config *types.DataSourceConfig) (*Config, error) {
+	if config == nil {
+		return nil, fmt.Errorf("%w: config is nil", datasource.ErrInvalidConfig)
+	}
+	merged := make(map[string]interface{}, len(config.Credentials)+len(settingKeys))
+	for k, v := range config.Credentials {
+		merged[k] = v
+	}
+	for _, k := range settingKeys {
+		if v, ok := config.Settings[k]; ok && v != "" && v != nil {
+			merged[k] = v
+		}
+	}
+	// The settings form may send the flag as a string.
+	if s, ok := merged["force_path_style"].(string); ok {
+		merged["force_path_style"] = s == "true"
+	}
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("marshal config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("parse bucket config: %w", err)
+	}
+
+	cfg.Provider = strings.ToLower(strings.TrimSpace(cfg.Provider))
+	if cfg.Provider == "" {
+		cfg.Provider = ProviderS3
+	}
+	cfg.Endpoint = strings.TrimRight(strings.TrimSpace(cfg.Endpoint), "/")
+	cfg.Region = strings.TrimSpace(cfg.Region)
+	cfg.Bucket = strings.TrimSpace(cfg.Bucket)
+	cfg.Prefix = normalizePrefix(cfg.Prefix)
+
+	switch cfg.Provider {
+	case ProviderS3, ProviderTOS, ProviderCOS:
+	default:
+		return nil, fmt.Errorf("%w: unsupported provider %q", datasource.ErrInvalidConfig, cfg.Provider)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("%w: bucket is required", datasource.ErrInvalidConfig)
+	}
+	if cfg.Endpoint == "" && cfg.Provider != ProviderS3 && cfg.Region == "" {
+		return nil, fmt.Errorf("%w: region is required for %s", datasource.ErrInvalidConfig, cfg.Provider)
+	}
+	if cfg.Endpoint != "" {
+		u, err := url.Parse(cfg.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("%w: endpoint must be an http(s) URL", datasource.ErrInvalidConfig)
+		}
+	}
+	if strings.TrimSpace(cfg.AccessKeyID) == "" || strings.TrimSpace(cfg.SecretAccessKey) == "" {
+		return nil, fmt.Errorf("%w: access_key_id and secret_access_key are required", datasource.ErrInvalidCredentials)
+	}
+	return &cfg, nil
+}
+
+// endpointURL returns the S3-compatible endpoint of the configuration, or
+// "" for the AWS default.
+func (c *Config) endpointURL() string {
+	if c.Endpoint != "" {
+		return c.Endpoint
+	}
+	switch c.Provider {
+	case ProviderTOS:
+		return "https://tos-s3-" + c.Region + ".volces.com"
+	case ProviderCOS:
+		return "https://cos." + c.Region + ".myqcloud.com"
+	}
+	return ""
+}
+
+// region returns the signing region; S3-compatible services that ignore it
+// still need a value.
+func (c *Config) region() string {
+	if c.Region != "" {
+		return c.Region
+	}
+	return "us-east-1"
+}
+
+// usePathStyle reports whether requests address the bucket as
+// endpoint/bucket/key. Custom endpoints of the s3 provider (MinIO and
+// other self-hosted services) default to path-style; TOS and COS require
+// virtual-hosted style.
+func (c *Config) usePathStyle() bool {
+	if c.ForcePathStyle {
+		return true
+	}
+	return c.Provider == ProviderS3 && c.Endpoint != "" && !strings.Contains(c.Endpoint, "amazonaws.com")
+}
+
+// bucketCursor stores incremental sync state: the ETag of every synced
+// object and the location they belong to.
+type bucketCursor struct {
+	LastSyncTime time.Time         `json:"last_sync_time"`
+	Location     string            `json:"location"`
+	Objects      map[string]string `json:"objects"` // key → ETag
+}
+
+// location identifies the synced bucket and prefix; a cursor for another
+// location is ignored.
+func (c *Config) location() string {
+	return c.endpointURL() + "/" + c.Bucket + "/" + c.Prefix
+}
+
+// normalizePrefix trims leading slashes and ensures a non-empty prefix ends
+// with "/".
+func normalizePrefix(prefix string) string {
+	prefix = strings.TrimLeft(strings.TrimSpace(prefix), "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return prefix
+}
+
+// itemExternalID scopes an object key to its bucket so keys cannot collide
+// across data sources of the same knowledge base.
+func itemExternalID(bucket, key string) string {
+	return bucket + "/" + key
+}
+
+// relativePath returns key relative to prefix.
+func relativePath(prefix, key string) string {
+	return strings.TrimPrefix(key, prefix)
+}
+
+// topLevelDir returns the first element of a path relative to the prefix,
+// or "" for an object directly under it.
+func topLevelDir(rel string) string {
+	dir, _, found := strings.Cut(rel, "/")
+	if !found {
+		return ""
+	}
+	return dir
+}
+
+// selected reports whether rel lies under one of the selected directories;
+// an empty selection selects the whole prefix.
+func selected(rel string, resourceIDs []string) bool {
+	if len(resourceIDs) == 0 {
+		return true
+	}
+	dir := topLevelDir(rel)
+	for _, id := range resourceIDs {
+		if id == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// isDocument reports whether the object at key is ingested: a document
+// extension, outside hidden directories, and not a directory marker.
+func isDocument(key string) bool {
+	if strings.HasSuffix(key, "/") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if strings.HasPrefix(part, ".") {
+			return false
+		}
+	}
+	return documentExtensions[strings.ToLower(path.Ext(key))]
+}
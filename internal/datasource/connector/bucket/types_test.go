@@ -0,0 +1,77 @@
+package bucket
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestParseConfig(t *testing.T) {
+	keys := map[string]interface{}{"access_key_id": "ak", "secret_access_key": "sk"}
+	tests := []struct {
+		name         string
+		settings     map[string]interface{}
+		creds        map[string]interface{}
+		wantErr      error
+		wantEndpoint string
+		wantPath     bool
+	}{
+		{"aws default", map[string]interface{}{"bucket": "docs"}, keys, nil, "", false},
+		{"tos", map[string]interface{}{"provider": "tos", "region": "cn-beijing", "bucket": "docs"}, keys,
+			nil, "https://tos-s3-cn-beijing.volces.com", false},
+		{"cos", map[string]interface{}{"provider": "COS", "region": "ap-guangzhou", "bucket": "docs-1250000000"}, keys,
+			nil, "https://cos.ap-guangzhou.myqcloud.com", false},
+		{"minio path style", map[string]interface{}{"endpoint": "http://minio:9000/", "bucket": "docs"}, keys,
+			nil, "http://minio:9000", true},
+		{"forced path style", map[string]interface{}{"provider": "tos", "region": "cn-beijing", "bucket": "docs", "force_path_style": "true"}, keys,
+			nil, "https://tos-s3-cn-beijing.volces.com", true},
+		{"missing bucket", map[string]interface{}{}, keys, datasource.ErrInvalidConfig, "", false},
+		{"tos without region", map[string]interface{}{"provider": "tos", "bucket": "docs"}, keys, datasource.ErrInvalidConfig, "", false},
+		{"unknown provider", map[string]interface{}{"provider": "oss", "bucket": "docs"}, keys, datasource.ErrInvalidConfig, "", false},
+		{"bad endpoint", map[string]interface{}{"endpoint": "minio:9000", "bucket": "docs"}, keys, datasource.ErrInvalidConfig, "", false},
+		{"missing keys", map[string]interface{}{"bucket": "docs"}, nil, datasource.ErrInvalidCredentials, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseConfig(&types.DataSourceConfig{Credentials: tt.creds, Settings: tt.settings})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.endpointURL() != tt.wantEndpoint || cfg.usePathStyle() != tt.wantPath {
+				t.Errorf("endpoint = %q path-style = %v, want %q %v",
+					cfg.endpointURL(), cfg.usePathStyle(), tt.wantEndpoint, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestNormalizePrefix(t *testing.T) {
+	for in, want := range map[string]string{"": "", "/inbox": "inbox/", "inbox/": "inbox/", " a/b ": "a/b/"} {
+		if got := normalizePrefix(in); got != want {
+			t.Errorf("normalizePrefix(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestIsDocument(t *testing.T) {
+	for key, want := range map[string]bool{
+		"a/report.PDF":   true,
+		"notes.md":       true,
+		"a/":             false,
+		"a/archive.zip":  false,
+		".hidden/doc.md": false,
+		"a/.draft.docx":  false,
+	} {
+		if got := isDocument(key); got != want {
+			t.Errorf("isDocument(%q) = %v, want %v", key, got, want)
+		}
+	}
+}
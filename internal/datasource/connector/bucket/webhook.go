@@ -0,0 +1,87 @@
+package bucket
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// eventPayload holds the fields of a bucket event notification. AWS S3 and
+// S3-compatible services put the object under "s3"; COS uses "cos".
+type eventPayload struct {
+	// Event is set on the test event S3 sends when a notification is
+	// configured.
+	Event   string `json:"Event"`
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Object struct {
+				Key string `json:"key"`
+			} `json:"object"`
+		} `json:"s3"`
+		COS struct {
+			Object struct {
+				Key string `json:"key"`
+			} `json:"cosObject"`
+		} `json:"cos"`
+	} `json:"Records"`
+}
+
+// VerifyWebhook authenticates a bucket event notification with the
+// configured webhook secret and reports whether it concerns an object under
+// the synced prefix.
+//
+// The secret is sent as "Authorization: Bearer <secret>" or in the
+// X-Webhook-Token header, which a notification target (or a relay such as a
+// cloud function) can be configured with. Deliveries that are not S3-style
+// event records trigger a sync, since their objects cannot be told apart;
+// the S3 test event does not.
+func (c *Connector) VerifyWebhook(config *types.DataSourceConfig, header http.Header, body []byte) (bool, error) {
+	cfg, err := parseConfig(config)
+	if err != nil {
+		return false, err
+	}
+	if cfg.WebhookSecret == "" {
+		return false, fmt.Errorf("%w: no webhook secret configured", datasource.ErrWebhookUnauthorized)
+	}
+	if !verifyToken(cfg.WebhookSecret, header) {
+		return false, datasource.ErrWebhookUnauthorized
+	}
+
+	var payload eventPayload
+	if err := json.Unmarshal(body, &payload); err != nil || len(payload.Records) == 0 {
+		return payload.Event != "s3:TestEvent", nil
+	}
+	for _, r := range payload.Records {
+		key := r.S3.Object.Key
+		if key == "" {
+			key = r.COS.Object.Key
+		}
+		// Keys are URL-encoded in S3 event records.
+		if decoded, err := url.QueryUnescape(key); err == nil {
+			key = decoded
+		}
+		if key == "" || strings.HasPrefix(strings.TrimLeft(key, "/"), cfg.Prefix) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// verifyToken checks the webhook token of a delivery.
+func verifyToken(secret string, header http.Header) bool {
+	token := header.Get("X-Webhook-Token")
+	if token == "" {
+		token = strings.TrimSpace(strings.TrimPrefix(header.Get("Authorization"), "Bearer "))
+	}
+	if token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+}
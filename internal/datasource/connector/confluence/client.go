@@ -0,0 +1,270 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/logger"
+)
+
+const (
+	defaultTimeout  = 30 * time.Second
+	defaultPageSize = 100
+	userAgent       = "WeKnora-Confluence-Connector/1.0"
+
+	// cqlDateLayout is the date format accepted by CQL date comparisons.
+	cqlDateLayout = "2006/01/02 15:04"
+)
+
+// client wraps the Confluence REST API v1.
+type client struct {
+	baseURL    string
+	email      string
+	token      string
+	httpClient *http.Client
+
+	// logTokenOnce ensures the redacted token identity is logged at most once
+	// per client lifetime.
+	logTokenOnce sync.Once
+}
+
+// newClient constructs a client with a normalized base URL.
+func newClient(cfg *Config) *client {
+	return &client{
+		baseURL:    cfg.GetBaseURL(),
+		email:      cfg.Email,
+		token:      cfg.APIToken,
+		httpClient: &http.Client{Timeout: defaultTimeout},
+	}
+}
+
+// setAuth authenticates req: Basic auth (email + API token) for Confluence
+// Cloud, Bearer for personal access tokens and OAuth 2.0 access tokens.
+func (c *client) setAuth(req *http.Request) {
+	if c.email != "" {
+		req.SetBasicAuth(c.email, c.token)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+}
+
+// doRequest executes an authenticated GET and decodes JSON, with retry logic
+// for transient errors (429, 5xx, transport failures). path is relative to
+// the base URL and may carry a query string.
+func (c *client) doRequest(ctx context.Context, path string, result interface{}) error {
+	const (
+		maxRetries    = 3
+		max5xxRetries = 1
+		retry5xxDelay = 2 * time.Second
+	)
+	var lastErr error
+	backoff := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second}
+
+	c.logTokenOnce.Do(func() {
+		logger.Infof(ctx, "[Confluence] client configured token=%s base=%s", redactToken(c.token), c.baseURL)
+	})
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+		if err != nil {
+			return fmt.Errorf("create request: %w", err)
+		}
+		c.setAuth(req)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("User-Agent", userAgent)
+
+		if attempt == 0 {
+			logger.Infof(ctx, "[Confluence] GET %s", path)
+		} else {
+			logger.Infof(ctx, "[Confluence] GET %s (retry %d/%d)", path, attempt, maxRetries)
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			lastErr = fmt.Errorf("execute request: %w", err)
+			if attempt < maxRetries {
+				if sErr := sleepCtx(ctx, backoff[attempt]); sErr != nil {
+					return sErr
+				}
+				continue
+			}
+			return lastErr
+		}
+
+		body, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if readErr != nil {
+			lastErr = fmt.Errorf("read response body: %w", readErr)
+			if attempt < maxRetries {
+				if sErr := sleepCtx(ctx, backoff[attempt]); sErr != nil {
+					return sErr
+				}
+				continue
+			}
+			return lastErr
+		}
+		bodyPreview := truncate(string(body), 500)
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			wait := parseRetryAfter(resp.Header.Get("Retry-After"), backoff[min(attempt, len(backoff)-1)])
+			lastErr = fmt.Errorf("confluence rate limited: status=429 body=%s", bodyPreview)
+			if attempt < maxRetries {
+				if sErr := sleepCtx(ctx, wait); sErr != nil {
+					return sErr
+				}
+				continue
+			}
+			return lastErr
+		}
+
+		if resp.StatusCode >= 500 && resp.StatusCode < 600 {
+			lastErr = fmt.Errorf("confluence server error: status=%d body=%s", resp.StatusCode, bodyPreview)
+			if attempt < max5xxRetries {
+				if sErr := sleepCtx(ctx, retry5xxDelay); sErr != nil {
+					return sErr
+				}
+				continue
+			}
+			return lastErr
+		}
+
+		// 401/403 → ErrInvalidCredentials so DataSourceService can tell a bad
+		// or expired token from transient failures.
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return fmt.Errorf("%w: status=%d body=%s", datasource.ErrInvalidCredentials, resp.StatusCode, bodyPreview)
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			var apiErr apiErrorBody
+			_ = json.Unmarshal(body, &apiErr)
+			if apiErr.Message != "" {
+				return fmt.Errorf("confluence api error: status=%d msg=%s", resp.StatusCode, apiErr.Message)
+			}
+			return fmt.Errorf("confluence api error: status=%d body=%s", resp.StatusCode, bodyPreview)
+		}
+
+		if result != nil {
+			if err := json.Unmarshal(body, result); err != nil {
+				return fmt.Errorf("decode response: %w", err)
+			}
+		}
+		return nil
+	}
+	return lastErr
+}
+
+// parseRetryAfter returns the Retry-After duration from the header, or
+// fallback if unparseable. "0" (or negative) is coerced to 100ms.
+func parseRetryAfter(header string, fallback time.Duration) time.Duration {
+	if header == "" {
+		return fallback
+	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		if secs <= 0 {
+			return 100 * time.Millisecond
+		}
+		return time.Duration(secs) * time.Second
+	}
+	return fallback
+}
+
+// sleepCtx pauses for d, returning early if ctx is cancelled.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// truncate returns s truncated to maxLen with "..." appended if longer.
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "..."
+}
+
+// Ping verifies the credentials by listing a single space.
+func (c *client) Ping(ctx context.Context) error {
+	var resp spaceListResponse
+	return c.doRequest(ctx, "/rest/api/space?limit=1", &resp)
+}
+
+// ListSpaces returns all current spaces visible to the credentials.
+func (c *client) ListSpaces(ctx context.Context) ([]space, error) {
+	q := url.Values{}
+	q.Set("status", "current")
+	q.Set("limit", strconv.Itoa(defaultPageSize))
+	path := "/rest/api/space?" + q.Encode()
+
+	var all []space
+	for path != "" {
+		var resp spaceListResponse
+		if err := c.doRequest(ctx, path, &resp); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Results...)
+		path = resp.Links.Next
+	}
+	return all, nil
+}
+
+// ListSpacePages returns every current page of a space (IDs and titles only).
+func (c *client) ListSpacePages(ctx context.Context, spaceKey string) ([]content, error) {
+	q := url.Values{}
+	q.Set("spaceKey", spaceKey)
+	q.Set("type", "page")
+	q.Set("status", "current")
+	q.Set("limit", strconv.Itoa(defaultPageSize))
+	return c.listContent(ctx, "/rest/api/content?"+q.Encode())
+}
+
+// SearchPagesModifiedSince returns the pages of a space modified at or after
+// since, using CQL. CQL compares in the user's time zone at minute
+// precision, so callers should pass a safety margin.
+func (c *client) SearchPagesModifiedSince(ctx context.Context, spaceKey string, since time.Time) ([]content, error) {
+	cql := fmt.Sprintf(`space = %q and type = page and lastmodified >= %q order by lastmodified`,
+		spaceKey, since.UTC().Format(cqlDateLayout))
+	q := url.Values{}
+	q.Set("cql", cql)
+	q.Set("expand", "version")
+	q.Set("limit", strconv.Itoa(defaultPageSize))
+	return c.listContent(ctx, "/rest/api/content/search?"+q.Encode())
+}
+
+// listContent walks a paginated content listing by following _links.next.
+func (c *client) listContent(ctx context.Context, path string) ([]content, error) {
+	var all []content
+	for path != "" {
+		var resp contentListResponse
+		if err := c.doRequest(ctx, path, &resp); err != nil {
+			return nil, err
+		}
+		all = append(all, resp.Results...)
+		path = resp.Links.Next
+	}
+	return all, nil
+}
+
+// GetPage fetches a page with its rendered body, version, ancestors and space.
+func (c *client) GetPage(ctx context.Context, pageID string) (content, error) {
+	q := url.Values{}
+	q.Set("expand", "body.export_view,body.storage,version,ancestors,space")
+	var page content
+	if err := c.doRequest(ctx, "/rest/api/content/"+url.PathEscape(pageID)+"?"+q.Encode(), &page); err != nil {
+		return content{}, err
+	}
+	return page, nil
+}
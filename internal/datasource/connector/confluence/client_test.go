@@ -0,0 +1,203 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+)
+
+// fakeConfluence sets up an httptest server emulating the Confluence REST API
+// v1 endpoints used by the connector.
+type fakeConfluence struct {
+	server *httptest.Server
+	mux    *http.ServeMux
+	calls  []string // path?query history
+
+	spaces     []space
+	spacePages map[string][]string // space key → page IDs
+	pages      map[string]content  // page ID → detail
+	searched   []content           // result of any CQL search
+	failPages  map[string]bool     // page IDs whose detail returns 500
+	lastCQL    string
+	lastAuth   string
+}
+
+func newFakeConfluence() *fakeConfluence {
+	f := &fakeConfluence{
+		mux:        http.NewServeMux(),
+		spacePages: make(map[string][]string),
+		pages:      make(map[string]content),
+		failPages:  make(map[string]bool),
+	}
+	f.server = httptest.NewServer(f.mux)
+	f.mux.HandleFunc("/rest/api/space", func(w http.ResponseWriter, r *http.Request) {
+		f.record(r)
+		writeJSON(w, spaceListResponse{Results: f.spaces})
+	})
+	f.mux.HandleFunc("/rest/api/content", func(w http.ResponseWriter, r *http.Request) {
+		f.record(r)
+		var results []content
+		for _, id := range f.spacePages[r.URL.Query().Get("spaceKey")] {
+			results = append(results, content{ID: id, Type: "page", Title: f.pages[id].Title})
+		}
+		writeJSON(w, contentListResponse{Results: results})
+	})
+	f.mux.HandleFunc("/rest/api/content/", func(w http.ResponseWriter, r *http.Request) {
+		f.record(r)
+		id := strings.TrimPrefix(r.URL.Path, "/rest/api/content/")
+		if id == "search" {
+			f.lastCQL = r.URL.Query().Get("cql")
+			writeJSON(w, contentListResponse{Results: f.searched})
+			return
+		}
+		if f.failPages[id] {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		page, ok := f.pages[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"statusCode":404,"message":"No content found"}`)
+			return
+		}
+		writeJSON(w, page)
+	})
+	return f
+}
+
+func (f *fakeConfluence) record(r *http.Request) {
+	f.calls = append(f.calls, r.URL.Path+"?"+r.URL.RawQuery)
+	f.lastAuth = r.Header.Get("Authorization")
+}
+
+func (f *fakeConfluence) Close() { f.server.Close() }
+
+func (f *fakeConfluence) cfg() *Config {
+	return &Config{BaseURL: f.server.URL, Email: "me@example.com", APIToken: "tok-super-secret-value-1234"}
+}
+
+// addPage registers a page of a space with the given version.
+func (f *fakeConfluence) addPage(spaceKey, id, title string, ver int, ancestors ...ancestor) {
+	f.spacePages[spaceKey] = append(f.spacePages[spaceKey], id)
+	f.pages[id] = content{
+		ID:        id,
+		Type:      "page",
+		Status:    "current",
+		Title:     title,
+		Version:   &version{Number: ver, When: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		Ancestors: ancestors,
+		Space:     &space{Key: spaceKey, Name: spaceKey + " space"},
+		Body:      &body{ExportView: &bodyValue{Value: "<p>Body of <strong>" + title + "</strong></p>"}},
+		Links:     links{WebUI: "/spaces/" + spaceKey + "/pages/" + id},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func TestClient_Ping_BasicAuthWithEmail(t *testing.T) {
+	f := newFakeConfluence()
+	defer f.Close()
+
+	if err := newClient(f.cfg()).Ping(context.Background()); err != nil {
+		t.Fatalf("Ping error: %v", err)
+	}
+	if !strings.HasPrefix(f.lastAuth, "Basic ") {
+		t.Errorf("Authorization = %q, want Basic auth", f.lastAuth)
+	}
+}
+
+func TestClient_Ping_BearerWithoutEmail(t *testing.T) {
+	f := newFakeConfluence()
+	defer f.Close()
+
+	cfg := f.cfg()
+	cfg.Email = ""
+	if err := newClient(cfg).Ping(context.Background()); err != nil {
+		t.Fatalf("Ping error: %v", err)
+	}
+	if f.lastAuth != "Bearer tok-super-secret-value-1234" {
+		t.Errorf("Authorization = %q, want Bearer token", f.lastAuth)
+	}
+}
+
+func TestClient_Ping_401WrapsInvalidCredentials(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(401)
+	}))
+	defer srv.Close()
+
+	err := newClient(&Config{BaseURL: srv.URL, APIToken: "bad"}).Ping(context.Background())
+	if !errors.Is(err, datasource.ErrInvalidCredentials) {
+		t.Errorf("401 should wrap ErrInvalidCredentials, got: %v", err)
+	}
+}
+
+func TestClient_429WithRetryAfter_Retries(t *testing.T) {
+	attempt := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempt++
+		if attempt == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(429)
+			return
+		}
+		writeJSON(w, spaceListResponse{})
+	}))
+	defer srv.Close()
+
+	if err := newClient(&Config{BaseURL: srv.URL, APIToken: "t"}).Ping(context.Background()); err != nil {
+		t.Fatalf("expected success after retry, got %v", err)
+	}
+	if attempt != 2 {
+		t.Errorf("attempts = %d, want 2", attempt)
+	}
+}
+
+func TestClient_ListSpaces_FollowsNextLink(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/rest/api/space", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("start") == "" {
+			writeJSON(w, spaceListResponse{
+				Results: []space{{Key: "ENG"}},
+				Links:   links{Next: "/rest/api/space?limit=1&start=1"},
+			})
+			return
+		}
+		writeJSON(w, spaceListResponse{Results: []space{{Key: "OPS"}}})
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	spaces, err := newClient(&Config{BaseURL: srv.URL, APIToken: "t"}).ListSpaces(context.Background())
+	if err != nil {
+		t.Fatalf("ListSpaces: %v", err)
+	}
+	if len(spaces) != 2 || spaces[0].Key != "ENG" || spaces[1].Key != "OPS" {
+		t.Errorf("spaces = %+v, want ENG and OPS", spaces)
+	}
+}
+
+func TestClient_SearchPagesModifiedSince_CQL(t *testing.T) {
+	f := newFakeConfluence()
+	defer f.Close()
+
+	since := time.Date(2024, 5, 1, 8, 30, 0, 0, time.FixedZone("CST", 8*3600))
+	if _, err := newClient(f.cfg()).SearchPagesModifiedSince(context.Background(), "ENG", since); err != nil {
+		t.Fatalf("SearchPagesModifiedSince: %v", err)
+	}
+	want := `space = "ENG" and type = page and lastmodified >= "2024/05/01 00:30"`
+	if !strings.HasPrefix(f.lastCQL, want) {
+		t.Errorf("cql = %q, want prefix %q", f.lastCQL, want)
+	}
+}
@@ -0,0 +1,299 @@
+package confluence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	htmltomd "github.com/JohannesKaufmann/html-to-markdown/v2"
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Compile-time proof that *Connector satisfies the datasource.Connector interface.
+var _ datasource.Connector = (*Connector)(nil)
+
+// modifiedSinceMargin is subtracted from the last sync time when searching
+// for changed pages: CQL compares dates in the token owner's time zone at
+// minute precision, so the margin absorbs any offset. Pages found again are
+// filtered out by their version number.
+const modifiedSinceMargin = 24 * time.Hour
+
+// Connector implements datasource.Connector for Confluence.
+type Connector struct{}
+
+// NewConnector creates a new Confluence connector.
+func NewConnector() *Connector { return &Connector{} }
+
+// Type returns the connector type identifier.
+func (c *Connector) Type() string { return types.ConnectorTypeConfluence }
+
+// Validate verifies the given credentials by listing a space.
+func (c *Connector) Validate(ctx context.Context, config *types.DataSourceConfig) error {
+	cfg, err := parseConfluenceConfig(config)
+	if err != nil {
+		return err
+	}
+	if err := newClient(cfg).Ping(ctx); err != nil {
+		return fmt.Errorf("confluence connection failed: %w", err)
+	}
+	return nil
+}
+
+// ResolveResourceAncestors has nothing to do for Confluence: spaces are a
+// flat list, so a selection has no ancestors to reveal.
+func (c *Connector) ResolveResourceAncestors(
+	ctx context.Context, config *types.DataSourceConfig, resourceIDs []string,
+) ([]string, error) {
+	return []string{}, nil
+}
+
+// ListResources returns the spaces visible to the credentials. Resource IDs
+// are space keys.
+func (c *Connector) ListResources(
+	ctx context.Context, config *types.DataSourceConfig, parentID string,
+) ([]types.Resource, error) {
+	if parentID != "" {
+		return []types.Resource{}, nil
+	}
+	cfg, err := parseConfluenceConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	spaces, err := newClient(cfg).ListSpaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list spaces: %w", err)
+	}
+
+	out := make([]types.Resource, 0, len(spaces))
+	for _, s := range spaces {
+		out = append(out, types.Resource{
+			ExternalID:  s.Key,
+			Name:        s.Name,
+			Type:        "space",
+			URL:         webURL(cfg.GetBaseURL(), s.Links.WebUI),
+			Description: s.Key,
+			Metadata: map[string]interface{}{
+				"space_type": s.Type,
+			},
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ExternalID < out[j].ExternalID })
+	return out, nil
+}
+
+// FetchAll performs a full sync of the pages of all spaces in resourceIDs.
+func (c *Connector) FetchAll(ctx context.Context, config *types.DataSourceConfig, resourceIDs []string) ([]types.FetchedItem, error) {
+	items, _, err := c.walk(ctx, config, resourceIDs, nil)
+	return items, err
+}
+
+// FetchIncremental returns pages changed (or deleted) since the prior cursor.
+// Changed pages are found with a CQL lastmodified search from the last sync
+// time; pages present in the prior cursor but no longer in their space are
+// emitted as IsDeleted=true items. Spaces without prior state get a full sync.
+func (c *Connector) FetchIncremental(
+	ctx context.Context,
+	config *types.DataSourceConfig,
+	cursor *types.SyncCursor,
+) ([]types.FetchedItem, *types.SyncCursor, error) {
+	if len(config.ResourceIDs) == 0 {
+		return nil, nil, fmt.Errorf("no resource IDs (space keys) configured")
+	}
+
+	prev := &confluenceCursor{}
+	if cursor != nil && cursor.ConnectorCursor != nil {
+		b, _ := json.Marshal(cursor.ConnectorCursor)
+		_ = json.Unmarshal(b, prev)
+	}
+
+	items, newCursor, err := c.walk(ctx, config, config.ResourceIDs, prev)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cursorMap := make(map[string]interface{})
+	b, _ := json.Marshal(newCursor)
+	_ = json.Unmarshal(b, &cursorMap)
+	return items, &types.SyncCursor{
+		LastSyncTime:    newCursor.LastSyncTime,
+		ConnectorCursor: cursorMap,
+	}, nil
+}
+
+// walk is the shared implementation for FetchAll / FetchIncremental. prev is
+// nil for a full sync, in which case no cursor is returned.
+func (c *Connector) walk(
+	ctx context.Context,
+	config *types.DataSourceConfig,
+	spaceKeys []string,
+	prev *confluenceCursor,
+) ([]types.FetchedItem, *confluenceCursor, error) {
+	cfg, err := parseConfluenceConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	cli := newClient(cfg)
+
+	newCursor := &confluenceCursor{LastSyncTime: time.Now(), SpacePages: make(map[string]map[string]int)}
+	var out []types.FetchedItem
+
+	for _, spaceKey := range spaceKeys {
+		pages, err := cli.ListSpacePages(ctx, spaceKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("list pages for space %s: %w", spaceKey, err)
+		}
+		current := make(map[string]bool, len(pages))
+		for _, p := range pages {
+			current[p.ID] = true
+		}
+
+		var prevPages map[string]int
+		if prev != nil {
+			prevPages = prev.SpacePages[spaceKey]
+		}
+		versions := make(map[string]int, len(pages))
+		toFetch := pages
+		if prevPages != nil && !prev.LastSyncTime.IsZero() {
+			changed, err := cli.SearchPagesModifiedSince(ctx, spaceKey, prev.LastSyncTime.Add(-modifiedSinceMargin))
+			if err != nil {
+				return nil, nil, fmt.Errorf("search changed pages for space %s: %w", spaceKey, err)
+			}
+			toFetch = changedPages(pages, changed, prevPages)
+			for id, v := range prevPages {
+				if current[id] {
+					versions[id] = v
+				}
+			}
+		}
+
+		for _, p := range toFetch {
+			item, v := c.fetchPage(ctx, cli, cfg.GetBaseURL(), spaceKey, p)
+			out = append(out, item)
+			if v > 0 {
+				versions[p.ID] = v
+			} else {
+				// Forget failed pages so the next sync retries them.
+				delete(versions, p.ID)
+			}
+		}
+		newCursor.SpacePages[spaceKey] = versions
+		logger.Infof(ctx, "[Confluence] space %s: pages=%d fetched=%d", spaceKey, len(pages), len(toFetch))
+
+		for pageID := range prevPages {
+			if !current[pageID] {
+				out = append(out, types.FetchedItem{
+					ExternalID:       pageID,
+					IsDeleted:        true,
+					SourceResourceID: spaceKey,
+				})
+			}
+		}
+	}
+
+	if prev == nil {
+		return out, nil, nil
+	}
+	return out, newCursor, nil
+}
+
+// changedPages returns the pages of a space to fetch during an incremental
+// sync: pages of the modified-since search whose version differs from the
+// synced one, plus pages not synced before (e.g. moved in from another
+// space, which the search does not report).
+func changedPages(pages, searched []content, prevPages map[string]int) []content {
+	current := make(map[string]bool, len(pages))
+	for _, p := range pages {
+		current[p.ID] = true
+	}
+	queued := make(map[string]bool)
+	var out []content
+	for _, p := range searched {
+		if !current[p.ID] || queued[p.ID] {
+			continue
+		}
+		if v, synced := prevPages[p.ID]; synced && v == pageVersion(&p) {
+			continue
+		}
+		queued[p.ID] = true
+		out = append(out, p)
+	}
+	for _, p := range pages {
+		if _, synced := prevPages[p.ID]; !synced && !queued[p.ID] {
+			queued[p.ID] = true
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// fetchPage downloads one page and converts it into a FetchedItem, also
+// returning the page version (0 on failure). Failures produce a placeholder
+// item carrying the error in its metadata.
+func (c *Connector) fetchPage(
+	ctx context.Context, cli *client, baseURL, spaceKey string, p content,
+) (types.FetchedItem, int) {
+	page, err := cli.GetPage(ctx, p.ID)
+	if err != nil {
+		return types.FetchedItem{
+			ExternalID:       p.ID,
+			Title:            p.Title,
+			SourceResourceID: spaceKey,
+			Metadata: map[string]string{
+				"error":     err.Error(),
+				"channel":   types.ChannelConfluence,
+				"page_id":   p.ID,
+				"space_key": spaceKey,
+			},
+		}, 0
+	}
+
+	var updatedAt time.Time
+	if page.Version != nil {
+		updatedAt = page.Version.When
+	}
+	return types.FetchedItem{
+		ExternalID:       page.ID,
+		Title:            page.Title,
+		Content:          []byte(pageMarkdown(&page)),
+		ContentType:      "text/markdown",
+		FileName:         sanitizeFileName(page.Title) + ".md",
+		URL:              webURL(baseURL, page.Links.WebUI),
+		UpdatedAt:        updatedAt,
+		SourceResourceID: spaceKey,
+		Metadata:         pageMetadata(spaceKey, &page),
+	}, pageVersion(&page)
+}
+
+// pageMarkdown converts a page body to Markdown, preferring the rendered
+// export view (macros expanded) over the raw storage format.
+func pageMarkdown(page *content) string {
+	var html string
+	if page.Body != nil {
+		switch {
+		case page.Body.ExportView != nil && strings.TrimSpace(page.Body.ExportView.Value) != "":
+			html = page.Body.ExportView.Value
+		case page.Body.Storage != nil:
+			html = page.Body.Storage.Value
+		}
+	}
+	md := strings.TrimSpace(html)
+	if md != "" {
+		if converted, err := htmltomd.ConvertString(html); err == nil && strings.TrimSpace(converted) != "" {
+			md = strings.TrimSpace(converted)
+		}
+	}
+	return "# " + page.Title + "\n\n" + md
+}
+
+// webURL joins the site base URL and an entity's webui link.
+func webURL(baseURL, webui string) string {
+	if webui == "" {
+		return baseURL
+	}
+	return baseURL + webui
+}
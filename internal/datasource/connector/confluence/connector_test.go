@@ -0,0 +1,202 @@
+package confluence
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func makeDSConfig(f *fakeConfluence, resourceIDs []string) *types.DataSourceConfig {
+	cfg := f.cfg()
+	return &types.DataSourceConfig{
+		Type: types.ConnectorTypeConfluence,
+		Credentials: map[string]interface{}{
+			"base_url":  cfg.BaseURL,
+			"email":     cfg.Email,
+			"api_token": cfg.APIToken,
+		},
+		ResourceIDs: resourceIDs,
+	}
+}
+
+// prevCursor builds the SyncCursor of an earlier sync of the given pages.
+func prevCursor(spaceKey string, pages map[string]int) *types.SyncCursor {
+	return &types.SyncCursor{ConnectorCursor: map[string]interface{}{
+		"last_sync_time": time.Now().Add(-time.Hour).Format(time.RFC3339),
+		"space_pages":    map[string]interface{}{spaceKey: pages},
+	}}
+}
+
+func TestConnector_Type(t *testing.T) {
+	if NewConnector().Type() != types.ConnectorTypeConfluence {
+		t.Errorf("Type() = %q, want %q", NewConnector().Type(), types.ConnectorTypeConfluence)
+	}
+}
+
+func TestConnector_ListResources_Spaces(t *testing.T) {
+	f := newFakeConfluence()
+	defer f.Close()
+	f.spaces = []space{
+		{Key: "OPS", Name: "Operations", Type: "global", Links: links{WebUI: "/spaces/OPS"}},
+		{Key: "ENG", Name: "Engineering", Type: "global"},
+	}
+
+	resources, err := NewConnector().ListResources(context.Background(), makeDSConfig(f, nil), "")
+	if err != nil {
+		t.Fatalf("ListResources: %v", err)
+	}
+	if len(resources) != 2 || resources[0].ExternalID != "ENG" || resources[1].ExternalID != "OPS" {
+		t.Fatalf("resources = %+v, want ENG, OPS", resources)
+	}
+	if resources[1].Type != "space" || resources[1].URL != f.server.URL+"/spaces/OPS" {
+		t.Errorf("OPS resource = %+v", resources[1])
+	}
+
+	children, err := NewConnector().ListResources(context.Background(), makeDSConfig(f, nil), "ENG")
+	if err != nil || len(children) != 0 {
+		t.Errorf("children = %v, %v; want none", children, err)
+	}
+}
+
+func TestConnector_FetchAll_MarkdownWithHierarchy(t *testing.T) {
+	f := newFakeConfluence()
+	defer f.Close()
+	f.addPage("ENG", "1", "Home", 3)
+	f.addPage("ENG", "2", "Deploy", 5, ancestor{ID: "1", Title: "Home"}, ancestor{ID: "7", Title: "Runbooks"})
+
+	items, err := NewConnector().FetchAll(context.Background(), makeDSConfig(f, nil), []string{"ENG"})
+	if err != nil {
+		t.Fatalf("FetchAll: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("len = %d, want 2", len(items))
+	}
+	deploy := items[1]
+	if deploy.ExternalID != "2" || deploy.SourceResourceID != "ENG" || deploy.FileName != "Deploy.md" {
+		t.Errorf("item = %+v", deploy)
+	}
+	if got := string(deploy.Content); !strings.Contains(got, "# Deploy") || !strings.Contains(got, "**Deploy**") {
+		t.Errorf("content = %q, want Markdown", got)
+	}
+	want := map[string]string{
+		"channel":   types.ChannelConfluence,
+		"space_key": "ENG",
+		"parent_id": "7",
+		"path":      "Home / Runbooks",
+		"version":   "5",
+	}
+	for k, v := range want {
+		if deploy.Metadata[k] != v {
+			t.Errorf("metadata[%s] = %q, want %q", k, deploy.Metadata[k], v)
+		}
+	}
+	if _, ok := items[0].Metadata["parent_id"]; ok {
+		t.Errorf("root page should have no parent_id, got %v", items[0].Metadata)
+	}
+}
+
+func TestConnector_FetchAll_PageError_EmitsPlaceholder(t *testing.T) {
+	f := newFakeConfluence()
+	defer f.Close()
+	f.addPage("ENG", "1", "Broken", 1)
+	f.failPages["1"] = true
+
+	items, err := NewConnector().FetchAll(context.Background(), makeDSConfig(f, nil), []string{"ENG"})
+	if err != nil {
+		t.Fatalf("FetchAll: %v", err)
+	}
+	if len(items) != 1 || items[0].Metadata["error"] == "" {
+		t.Fatalf("items = %+v, want one placeholder with error", items)
+	}
+}
+
+func TestConnector_FetchIncremental_FirstSync(t *testing.T) {
+	f := newFakeConfluence()
+	defer f.Close()
+	f.addPage("ENG", "1", "Home", 3)
+
+	items, cursor, err := NewConnector().FetchIncremental(context.Background(), makeDSConfig(f, []string{"ENG"}), nil)
+	if err != nil {
+		t.Fatalf("FetchIncremental: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("len = %d, want 1", len(items))
+	}
+	if f.lastCQL != "" {
+		t.Errorf("first sync should not search, cql = %q", f.lastCQL)
+	}
+	pages := cursor.ConnectorCursor["space_pages"].(map[string]interface{})["ENG"].(map[string]interface{})
+	if pages["1"] != float64(3) {
+		t.Errorf("cursor pages = %v, want 1 → 3", pages)
+	}
+}
+
+func TestConnector_FetchIncremental_ReturnsOnlyChanged(t *testing.T) {
+	f := newFakeConfluence()
+	defer f.Close()
+	f.addPage("ENG", "1", "Home", 3)
+	f.addPage("ENG", "2", "Deploy", 6)
+	f.addPage("ENG", "3", "New", 1)
+	// The search window overlaps the previous sync, so it also reports page
+	// 1 at the already-synced version.
+	f.searched = []content{
+		{ID: "1", Version: &version{Number: 3}},
+		{ID: "2", Version: &version{Number: 6}},
+	}
+
+	items, cursor, err := NewConnector().FetchIncremental(context.Background(),
+		makeDSConfig(f, []string{"ENG"}), prevCursor("ENG", map[string]int{"1": 3, "2": 5}))
+	if err != nil {
+		t.Fatalf("FetchIncremental: %v", err)
+	}
+	got := make(map[string]bool)
+	for _, it := range items {
+		got[it.ExternalID] = true
+	}
+	if len(items) != 2 || !got["2"] || !got["3"] {
+		t.Errorf("items = %v, want pages 2 and 3", got)
+	}
+	if !strings.Contains(f.lastCQL, `space = "ENG"`) {
+		t.Errorf("cql = %q", f.lastCQL)
+	}
+	pages := cursor.ConnectorCursor["space_pages"].(map[string]interface{})["ENG"].(map[string]interface{})
+	if pages["1"] != float64(3) || pages["2"] != float64(6) || pages["3"] != float64(1) {
+		t.Errorf("cursor pages = %v", pages)
+	}
+}
+
+func TestConnector_FetchIncremental_DetectsDeletion(t *testing.T) {
+	f := newFakeConfluence()
+	defer f.Close()
+	f.addPage("ENG", "1", "Home", 3)
+
+	items, _, err := NewConnector().FetchIncremental(context.Background(),
+		makeDSConfig(f, []string{"ENG"}), prevCursor("ENG", map[string]int{"1": 3, "9": 2}))
+	if err != nil {
+		t.Fatalf("FetchIncremental: %v", err)
+	}
+	if len(items) != 1 || items[0].ExternalID != "9" || !items[0].IsDeleted || items[0].SourceResourceID != "ENG" {
+		t.Errorf("items = %+v, want deletion of page 9", items)
+	}
+}
+
+func TestConnector_FetchIncremental_FailedPageRetriedNextSync(t *testing.T) {
+	f := newFakeConfluence()
+	defer f.Close()
+	f.addPage("ENG", "1", "Home", 4)
+	f.failPages["1"] = true
+	f.searched = []content{{ID: "1", Version: &version{Number: 4}}}
+
+	_, cursor, err := NewConnector().FetchIncremental(context.Background(),
+		makeDSConfig(f, []string{"ENG"}), prevCursor("ENG", map[string]int{"1": 3}))
+	if err != nil {
+		t.Fatalf("FetchIncremental: %v", err)
+	}
+	pages := cursor.ConnectorCursor["space_pages"].(map[string]interface{})["ENG"].(map[string]interface{})
+	if _, ok := pages["1"]; ok {
+		t.Errorf("failed page should be dropped from the cursor, got %v", pages)
+	}
+}
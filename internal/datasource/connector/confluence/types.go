@@ -0,0 +1,240 @@
+// Package confluence implements the Atlassian Confluence data source connector
+// for WeKnora.
+//
+// It syncs the pages of selected spaces into WeKnora knowledge bases as
+// Markdown, recording each page's position in the page tree in the knowledge
+// metadata. Confluence Cloud and Data Center / Server are both supported
+// through the REST API v1.
+//
+// Confluence API docs:
+//   - Authentication: Cloud — Basic auth with account email + API token
+//     (https://id.atlassian.com/manage-profile/security/api-tokens);
+//     Data Center — Bearer personal access token; OAuth 2.0 (3LO) — Bearer
+//     access token against https://api.atlassian.com/ex/confluence/{cloudId}
+//   - Spaces:  GET /rest/api/space
+//   - Pages:   GET /rest/api/content?spaceKey=..&type=page (list),
+//     GET /rest/api/content/{id}?expand=body.export_view (detail)
+//   - Search:  GET /rest/api/content/search?cql=.. (changes since the last sync)
+//
+// Known limitations (v1):
+//   - Only pages are synced (blog posts, comments and attachments are skipped)
+//   - Macros are synced as rendered by Confluence's export view
+package confluence
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Config holds Confluence-specific configuration.
+type Config struct {
+	// BaseURL is the site URL, e.g. https://your-domain.atlassian.net/wiki
+	// for Confluence Cloud or https://confluence.example.com for Data Center.
+	BaseURL string `json:"base_url"`
+
+	// Email is the Atlassian account email. When set, requests use Basic auth
+	// with APIToken (Confluence Cloud API tokens); otherwise APIToken is sent
+	// as a Bearer token (Data Center personal access tokens, OAuth 2.0 access
+	// tokens).
+	Email string `json:"email,omitempty"`
+
+	// APIToken is the API token, personal access token or OAuth access token.
+	APIToken string `json:"api_token"`
+}
+
+// GetBaseURL returns the normalized base URL:
+//   - missing scheme → prepend "https://"
+//   - trailing slash → stripped
+func (c *Config) GetBaseURL() string {
+	url := strings.TrimSpace(c.BaseURL)
+	if url != "" && !strings.Contains(url, "://") {
+		url = "https://" + url
+	}
+	return strings.TrimRight(url, "/")
+}
+
+// parseConfluenceConfig extracts and validates Confluence-specific configuration.
+func parseConfluenceConfig(config *types.DataSourceConfig) (*Config, error) {
+	if config == nil {
+		return nil, fmt.Errorf("%w: config is nil", datasource.ErrInvalidConfig)
+	}
+	credBytes, err := json.Marshal(config.Credentials)
+	if err != nil {
+		return nil, fmt.Errorf("marshal credentials: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(credBytes, &cfg); err != nil {
+		return nil, fmt.Errorf("parse confluence credentials: %w", err)
+	}
+	cfg.Email = strings.TrimSpace(cfg.Email)
+	if strings.TrimSpace(cfg.APIToken) == "" {
+		return nil, fmt.Errorf("%w: api_token is required", datasource.ErrInvalidCredentials)
+	}
+	if cfg.GetBaseURL() == "" {
+		return nil, fmt.Errorf("%w: base_url is required", datasource.ErrInvalidConfig)
+	}
+	return &cfg, nil
+}
+
+// --- Confluence API response types ---
+
+// links holds the _links object of a response or an entity.
+type links struct {
+	Base  string `json:"base"`
+	WebUI string `json:"webui"`
+	Next  string `json:"next"`
+}
+
+// apiErrorBody is the error body shape Confluence returns on non-2xx.
+type apiErrorBody struct {
+	StatusCode int    `json:"statusCode"`
+	Message    string `json:"message"`
+}
+
+// spaceListResponse wraps GET /rest/api/space.
+type spaceListResponse struct {
+	Results []space `json:"results"`
+	Links   links   `json:"_links"`
+}
+
+type space struct {
+	ID     int64  `json:"id"`
+	Key    string `json:"key"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`   // "global" | "personal"
+	Status string `json:"status"` // "current" | "archived"
+	Links  links  `json:"_links"`
+}
+
+// contentListResponse wraps GET /rest/api/content and /rest/api/content/search.
+type contentListResponse struct {
+	Results []content `json:"results"`
+	Links   links     `json:"_links"`
+}
+
+// content is a page. Version, ancestors, space and body are only present
+// when expanded.
+type content struct {
+	ID        string     `json:"id"`
+	Type      string     `json:"type"`   // "page" | "blogpost" | ...
+	Status    string     `json:"status"` // "current" | "trashed" | "draft"
+	Title     string     `json:"title"`
+	Version   *version   `json:"version,omitempty"`
+	Ancestors []ancestor `json:"ancestors,omitempty"`
+	Space     *space     `json:"space,omitempty"`
+	Body      *body      `json:"body,omitempty"`
+	Links     links      `json:"_links"`
+}
+
+type version struct {
+	Number int       `json:"number"`
+	When   time.Time `json:"when"`
+	By     struct {
+		DisplayName string `json:"displayName"`
+	} `json:"by"`
+}
+
+// ancestor is a page above a page in the page tree, root first.
+type ancestor struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+}
+
+type body struct {
+	ExportView *bodyValue `json:"export_view,omitempty"`
+	Storage    *bodyValue `json:"storage,omitempty"`
+}
+
+type bodyValue struct {
+	Value string `json:"value"`
+}
+
+// confluenceCursor stores incremental sync state.
+// SpacePages: space key → page id → version number of the synced page.
+type confluenceCursor struct {
+	LastSyncTime time.Time                 `json:"last_sync_time"`
+	SpacePages   map[string]map[string]int `json:"space_pages,omitempty"`
+}
+
+// pageVersion returns the version number of a page (0 when not expanded).
+func pageVersion(c *content) int {
+	if c.Version == nil {
+		return 0
+	}
+	return c.Version.Number
+}
+
+// ancestorPath returns the titles of a page's ancestors joined root first,
+// e.g. "Engineering / Runbooks".
+func ancestorPath(ancestors []ancestor) string {
+	titles := make([]string, 0, len(ancestors))
+	for _, a := range ancestors {
+		titles = append(titles, a.Title)
+	}
+	return strings.Join(titles, " / ")
+}
+
+// pageMetadata returns the knowledge metadata of a synced page: its space,
+// version and position in the page tree.
+func pageMetadata(spaceKey string, c *content) map[string]string {
+	meta := map[string]string{
+		"channel":   types.ChannelConfluence,
+		"page_id":   c.ID,
+		"space_key": spaceKey,
+	}
+	if c.Space != nil && c.Space.Name != "" {
+		meta["space_name"] = c.Space.Name
+	}
+	if n := len(c.Ancestors); n > 0 {
+		meta["parent_id"] = c.Ancestors[n-1].ID
+		meta["path"] = ancestorPath(c.Ancestors)
+	}
+	if c.Version != nil {
+		meta["version"] = strconv.Itoa(c.Version.Number)
+		if c.Version.By.DisplayName != "" {
+			meta["author"] = c.Version.By.DisplayName
+		}
+	}
+	return meta
+}
+
+// sanitizeFileName removes characters that are invalid in filenames and
+// truncates to a safe length at a UTF-8 rune boundary.
+func sanitizeFileName(name string) string {
+	if strings.TrimSpace(name) == "" {
+		return "untitled"
+	}
+	replacer := strings.NewReplacer(
+		"/", "_", "\\", "_", ":", "_", "*", "_",
+		"?", "_", "\"", "_", "<", "_", ">", "_", "|", "_",
+	)
+	result := replacer.Replace(name)
+	const maxBytes = 200
+	if len(result) > maxBytes {
+		result = result[:maxBytes]
+		for len(result) > 0 {
+			r, size := utf8.DecodeLastRuneInString(result)
+			if r != utf8.RuneError || size != 1 {
+				break
+			}
+			result = result[:len(result)-1]
+		}
+	}
+	return result
+}
+
+// redactToken returns a log-safe form of a token: its first 6 and last 4
+// characters, or "***" for short tokens.
+func redactToken(t string) string {
+	if len(t) < 12 {
+		return "***"
+	}
+	return t[:6] + "..." + t[len(t)-4:]
+}
@@ -0,0 +1,58 @@
+package confluence
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestParseConfluenceConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		creds   map[string]interface{}
+		wantErr error
+	}{
+		{"ok", map[string]interface{}{"base_url": "x.atlassian.net/wiki", "api_token": "t"}, nil},
+		{"missing token", map[string]interface{}{"base_url": "https://x.atlassian.net/wiki"}, datasource.ErrInvalidCredentials},
+		{"missing base url", map[string]interface{}{"api_token": "t"}, datasource.ErrInvalidConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseConfluenceConfig(&types.DataSourceConfig{Credentials: tt.creds})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.GetBaseURL() != "https://x.atlassian.net/wiki" {
+				t.Errorf("GetBaseURL() = %q", cfg.GetBaseURL())
+			}
+		})
+	}
+}
+
+func TestPageMetadata_Hierarchy(t *testing.T) {
+	meta := pageMetadata("ENG", &content{
+		ID:        "42",
+		Ancestors: []ancestor{{ID: "1", Title: "Home"}, {ID: "7", Title: "Runbooks"}},
+		Version:   &version{Number: 2},
+	})
+	if meta["parent_id"] != "7" || meta["path"] != "Home / Runbooks" || meta["version"] != "2" {
+		t.Errorf("metadata = %v", meta)
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	if got := sanitizeFileName("a/b: c?"); got != "a_b_ c_" {
+		t.Errorf("sanitizeFileName = %q", got)
+	}
+	if got := sanitizeFileName("  "); got != "untitled" {
+		t.Errorf("sanitizeFileName(blank) = %q", got)
+	}
+}
@@ -0,0 +1,210 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+const (
+	// commandTimeout bounds a single git command (clones of large
+	// repositories included).
+	commandTimeout = 10 * time.Minute
+
+	// maxFileSize skips files larger than this; they are almost always
+	// generated or minified.
+	maxFileSize = 1 << 20 // 1 MB
+
+	// defaultUsername goes with a token in basic auth when none is set.
+	defaultUsername = "git"
+)
+
+// errCommitNotFound is returned by diff when the previous commit is not in
+// the history of the branch any more.
+var errCommitNotFound = errors.New("commit not found")
+
+// client runs the git binary against one repository.
+type client struct {
+	repoURL  string
+	branch   string
+	username string
+	token    string
+}
+
+func newClient(cfg *Config) *client {
+	username := cfg.Username
+	if username == "" {
+		username = defaultUsername
+	}
+	return &client{repoURL: cfg.RepoURL, branch: cfg.Branch, username: username, token: cfg.Token}
+}
+
+// env returns the environment of git commands. Configuration goes through
+// GIT_CONFIG_* so that the token never shows on the command line: only the
+// http(s) protocols are allowed, redirects are not followed and the token
+// is sent as basic auth.
+func (c *client) env() []string {
+	configs := [][2]string{
+		{"protocol.allow", "never"},
+		{"protocol.http.allow", "always"},
+		{"protocol.https.allow", "always"},
+		{"http.followRedirects", "false"},
+	}
+	if c.token != "" {
+		auth := base64.StdEncoding.EncodeToString([]byte(c.username + ":" + c.token))
+		configs = append(configs, [2]string{"http.extraHeader", "Authorization: Basic " + auth})
+	}
+	env := append(os.Environ(),
+		"GIT_TERMINAL_PROMPT=0",
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_LFS_SKIP_SMUDGE=1",
+		"GIT_CONFIG_COUNT="+strconv.Itoa(len(configs)),
+	)
+	for i, kv := range configs {
+		env = append(env,
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, kv[0]),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, kv[1]),
+		)
+	}
+	return env
+}
+
+// run runs git with args in dir and returns its standard output.
+func (c *client) run(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = c.env()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if len(msg) > 500 {
+			msg = msg[:500]
+		}
+		return nil, fmt.Errorf("git %s: %w: %s", args[0], err, msg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// checkURL rejects clone URLs that point at internal addresses.
+func (c *client) checkURL() error {
+	if err := utils.ValidateURLForSSRF(c.repoURL); err != nil {
+		return fmt.Errorf("repo_url rejected: %w", err)
+	}
+	return nil
+}
+
+// lsRemote checks that the repository is reachable with the configured
+// credentials and that the branch exists.
+func (c *client) lsRemote(ctx context.Context) error {
+	if err := c.checkURL(); err != nil {
+		return err
+	}
+	ref := "HEAD"
+	if c.branch != "" {
+		ref = "refs/heads/" + c.branch
+	}
+	out, err := c.run(ctx, "", "ls-remote", "--", c.repoURL, ref)
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return fmt.Errorf("branch %q not found", c.branch)
+	}
+	return nil
+}
+
+// clone clones the branch into dir. A shallow clone holds the head commit
+// only; otherwise the whole history of the branch is fetched, without the
+// contents of old files (partial clone), so that commits can be diffed.
+// With checkout false no working tree is written.
+func (c *client) clone(ctx context.Context, dir string, shallow, checkout bool) error {
+	if err := c.checkURL(); err != nil {
+		return err
+	}
+	args := []string{"clone", "--quiet", "--single-branch", "--no-tags"}
+	if c.branch != "" {
+		args = append(args, "--branch", c.branch)
+	}
+	if shallow {
+		args = append(args, "--depth", "1")
+	}
+	if !shallow || !checkout {
+		args = append(args, "--filter=blob:none")
+	}
+	if !checkout {
+		args = append(args, "--no-checkout")
+	}
+	_, err := c.run(ctx, "", append(args, "--", c.repoURL, dir)...)
+	return err
+}
+
+// head returns the head commit of the clone in dir and its commit time.
+func (c *client) head(ctx context.Context, dir string) (string, time.Time, error) {
+	out, err := c.run(ctx, dir, "log", "-1", "--format=%H %cI")
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	sha, date, _ := strings.Cut(strings.TrimSpace(string(out)), " ")
+	committed, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		committed = time.Now().UTC()
+	}
+	return sha, committed, nil
+}
+
+// listFiles returns the paths of the files of the head commit.
+func (c *client) listFiles(ctx context.Context, dir string) ([]string, error) {
+	out, err := c.run(ctx, dir, "ls-tree", "-r", "-z", "--name-only", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return splitNUL(out), nil
+}
+
+// fileChange is a file added, modified or deleted between two commits.
+type fileChange struct {
+	path    string
+	deleted bool
+}
+
+// diff returns the files changed between commit and the head of the clone
+// in dir. Renames are reported as a deletion and an addition.
+func (c *client) diff(ctx context.Context, dir, commit string) ([]fileChange, error) {
+	if _, err := c.run(ctx, dir, "cat-file", "-e", commit+"^{commit}"); err != nil {
+		return nil, errCommitNotFound
+	}
+	out, err := c.run(ctx, dir, "diff", "--name-status", "-z", "--no-renames", commit, "HEAD", "--")
+	if err != nil {
+		return nil, err
+	}
+	fields := splitNUL(out)
+	changes := make([]fileChange, 0, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		changes = append(changes, fileChange{path: fields[i+1], deleted: fields[i] == "D"})
+	}
+	return changes, nil
+}
+
+func splitNUL(b []byte) []string {
+	var out []string
+	for _, s := range strings.Split(string(b), "\x00") {
+		if s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
@@ -0,0 +1,269 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/infrastructure/docparser"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Compile-time proof that *Connector satisfies the datasource interfaces.
+var (
+	_ datasource.Connector        = (*Connector)(nil)
+	_ datasource.WebhookConnector = (*Connector)(nil)
+)
+
+// Connector implements datasource.Connector for Git repositories.
+type Connector struct{}
+
+// NewConnector creates a new Git connector.
+func NewConnector() *Connector { return &Connector{} }
+
+// Type returns the connector type identifier.
+func (c *Connector) Type() string { return types.ConnectorTypeGit }
+
+// Validate checks that the repository is reachable with the configured
+// credentials and that the branch exists.
+func (c *Connector) Validate(ctx context.Context, config *types.DataSourceConfig) error {
+	cfg, err := parseConfig(config)
+	if err != nil {
+		return err
+	}
+	return newClient(cfg).lsRemote(ctx)
+}
+
+// ResolveResourceAncestors has nothing to do: the top-level directories are
+// a flat list.
+func (c *Connector) ResolveResourceAncestors(
+	ctx context.Context, config *types.DataSourceConfig, resourceIDs []string,
+) ([]string, error) {
+	return []string{}, nil
+}
+
+// ListResources returns the top-level directories of the branch that hold
+// files ingested from repositories, with their file count. Only the tree of
+// the head commit is fetched.
+func (c *Connector) ListResources(
+	ctx context.Context, config *types.DataSourceConfig, parentID string,
+) ([]types.Resource, error) {
+	if parentID != "" {
+		return []types.Resource{}, nil
+	}
+	cfg, err := parseConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	cli := newClient(cfg)
+	dir, cleanup, err := tempDir()
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+	if err := cli.clone(ctx, dir, true, false); err != nil {
+		return nil, err
+	}
+	paths, err := cli.listFiles(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, p := range paths {
+		if d := topLevelDir(p); d != "" && docparser.IsRepositoryFile(p) {
+			counts[d]++
+		}
+	}
+	out := make([]types.Resource, 0, len(counts))
+	for d, n := range counts {
+		out = append(out, types.Resource{
+			ExternalID: d,
+			Name:       d,
+			Type:       "directory",
+			Metadata:   map[string]interface{}{"file_count": n},
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// FetchAll returns every file of the selected directories (the whole
+// repository when resourceIDs is empty) at the head of the branch.
+func (c *Connector) FetchAll(
+	ctx context.Context, config *types.DataSourceConfig, resourceIDs []string,
+) ([]types.FetchedItem, error) {
+	cfg, err := parseConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	items, _, err := c.sync(ctx, cfg, resourceIDs, nil)
+	return items, err
+}
+
+// FetchIncremental returns the files added, modified or deleted since the
+// commit of the cursor, or every file when there is no usable cursor.
+func (c *Connector) FetchIncremental(
+	ctx context.Context, config *types.DataSourceConfig, cursor *types.SyncCursor,
+) ([]types.FetchedItem, *types.SyncCursor, error) {
+	cfg, err := parseConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	var prev *gitCursor
+	if cursor != nil && cursor.ConnectorCursor != nil {
+		var p gitCursor
+		b, err := json.Marshal(cursor.ConnectorCursor)
+		if err != nil {
+			logger.Warnf(ctx, "[Git] marshal connector cursor: %v", err)
+		} else if err := json.Unmarshal(b, &p); err != nil {
+			logger.Warnf(ctx, "[Git] unmarshal connector cursor: %v", err)
+		} else if p.Commit != "" && p.RepoURL == cfg.RepoURL && p.Branch == cfg.Branch {
+			prev = &p
+		}
+	}
+
+	items, newCursor, err := c.sync(ctx, cfg, config.ResourceIDs, prev)
+	if err != nil {
+		return nil, nil, err
+	}
+	cursorMap := make(map[string]interface{})
+	b, err := json.Marshal(newCursor)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(b, &cursorMap); err != nil {
+		return nil, nil, err
+	}
+	return items, &types.SyncCursor{LastSyncTime: newCursor.LastSyncTime, ConnectorCursor: cursorMap}, nil
+}
+
+// sync clones the branch and returns the items to ingest: the files changed
+// since prev when it is set and still in the history, every selected file
+// otherwise.
+func (c *Connector) sync(
+	ctx context.Context, cfg *Config, resourceIDs []string, prev *gitCursor,
+) ([]types.FetchedItem, *gitCursor, error) {
+	cli := newClient(cfg)
+	dir, cleanup, err := tempDir()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cleanup()
+	// Diffing against the previous commit needs the history; a full sync
+	// only needs the head.
+	if err := cli.clone(ctx, dir, prev == nil, true); err != nil {
+		return nil, nil, err
+	}
+	commit, committedAt, err := cli.head(ctx, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	cursor := &gitCursor{LastSyncTime: time.Now().UTC(), Commit: commit, RepoURL: cfg.RepoURL, Branch: cfg.Branch}
+
+	var changes []fileChange
+	if prev != nil {
+		if prev.Commit == commit {
+			logger.Infof(ctx, "[Git] %s: no new commits since %s", cfg.RepoURL, shortSHA(commit))
+			return nil, cursor, nil
+		}
+		changes, err = cli.diff(ctx, dir, prev.Commit)
+		if errors.Is(err, errCommitNotFound) {
+			logger.Warnf(ctx, "[Git] %s: commit %s is not in the history any more, syncing all files",
+				cfg.RepoURL, shortSHA(prev.Commit))
+			prev = nil
+		} else if err != nil {
+			return nil, nil, err
+		}
+	}
+	if prev == nil {
+		paths, err := cli.listFiles(ctx, dir)
+		if err != nil {
+			return nil, nil, err
+		}
+		changes = make([]fileChange, len(paths))
+		for i, p := range paths {
+			changes[i] = fileChange{path: p}
+		}
+	}
+
+	var out []types.FetchedItem
+	var skipped int
+	for _, ch := range changes {
+		if !docparser.IsRepositoryFile(ch.path) || !selected(ch.path, resourceIDs) {
+			continue
+		}
+		item := types.FetchedItem{
+			ExternalID:       itemExternalID(cfg.RepoURL, ch.path),
+			Title:            ch.path,
+			FileName:         ch.path,
+			UpdatedAt:        committedAt,
+			IsDeleted:        ch.deleted,
+			SourceResourceID: topLevelDir(ch.path),
+			Metadata: map[string]string{
+				"channel":   types.ChannelGit,
+				"file_path": ch.path,
+				"repo_url":  cfg.RepoURL,
+				"branch":    cfg.Branch,
+				"commit":    commit,
+			},
+		}
+		if !ch.deleted {
+			content, ok := readFile(dir, ch.path)
+			if !ok {
+				skipped++
+				continue
+			}
+			item.Content = content
+			item.ContentType = "text/plain"
+			if docparser.CodeLanguage(ch.path) == "" {
+				item.ContentType = "text/markdown"
+			}
+		}
+		out = append(out, item)
+	}
+
+	mode := "full"
+	if prev != nil {
+		mode = "incremental from " + shortSHA(prev.Commit)
+	}
+	logger.Infof(ctx, "[Git] %s@%s (%s): %d items, %d skipped", cfg.RepoURL, shortSHA(commit), mode, len(out), skipped)
+	return out, cursor, nil
+}
+
+// readFile reads a regular file of the working tree in dir. Symbolic links,
+// oversized and binary files are skipped.
+func readFile(dir, path string) ([]byte, bool) {
+	full := filepath.Join(dir, filepath.FromSlash(path))
+	info, err := os.Lstat(full)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxFileSize {
+		return nil, false
+	}
+	content, err := os.ReadFile(full)
+	if err != nil || bytes.IndexByte(content, 0) >= 0 {
+		return nil, false
+	}
+	return content, true
+}
+
+func tempDir() (string, func(), error) {
+	dir, err := os.MkdirTemp("", "weknora-git-")
+	if err != nil {
+		return "", nil, err
+	}
+	return filepath.Join(dir, "repo"), func() { _ = os.RemoveAll(dir) }, nil
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
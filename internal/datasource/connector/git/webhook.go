@@ -0,0 +1,95 @@
+package git
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// pushPayload holds the fields of a push event shared by GitHub, Gitea and
+// GitLab.
+type pushPayload struct {
+	Ref        string `json:"ref"`
+	Repository struct {
+		DefaultBranch string `json:"default_branch"`
+	} `json:"repository"`
+	Project struct {
+		DefaultBranch string `json:"default_branch"`
+	} `json:"project"`
+}
+
+// VerifyWebhook authenticates a push webhook with the configured webhook
+// secret and reports whether it is a push to the synced branch.
+//
+// GitHub and Gitea sign the body with HMAC-SHA256 (X-Hub-Signature-256,
+// X-Gitea-Signature); GitLab sends the secret itself (X-Gitlab-Token).
+// Other events, such as the ping sent when a webhook is created, are
+// accepted without triggering a sync.
+func (c *Connector) VerifyWebhook(config *types.DataSourceConfig, header http.Header, body []byte) (bool, error) {
+	cfg, err := parseConfig(config)
+	if err != nil {
+		return false, err
+	}
+	if cfg.WebhookSecret == "" {
+		return false, fmt.Errorf("%w: no webhook secret configured", datasource.ErrWebhookUnauthorized)
+	}
+	if !verifySignature(cfg.WebhookSecret, header, body) {
+		return false, datasource.ErrWebhookUnauthorized
+	}
+
+	event := firstHeader(header, "X-GitHub-Event", "X-Gitea-Event", "X-Gogs-Event", "X-Gitlab-Event")
+	if event != "push" && event != "Push Hook" {
+		return false, nil
+	}
+	var payload pushPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return false, fmt.Errorf("parse push payload: %w", err)
+	}
+	branch := cfg.Branch
+	if branch == "" {
+		branch = payload.Repository.DefaultBranch
+		if branch == "" {
+			branch = payload.Project.DefaultBranch
+		}
+	}
+	// Without a known branch any push may concern the synced one.
+	return branch == "" || payload.Ref == "refs/heads/"+branch, nil
+}
+
+// verifySignature checks the webhook signature or token of a delivery.
+func verifySignature(secret string, header http.Header, body []byte) bool {
+	if token := header.Get("X-Gitlab-Token"); token != "" {
+		return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+	}
+	sig := strings.TrimPrefix(header.Get("X-Hub-Signature-256"), "sha256=")
+	if sig == "" {
+		sig = header.Get("X-Gitea-Signature")
+	}
+	if sig == "" {
+		return false
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+func firstHeader(header http.Header, names ...string) string {
+	for _, name := range names {
+		if v := header.Get(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,397 @@
+package imap
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+const (
+	commandTimeout = 60 * time.Second
+	// maxMessageSize skips messages larger than this (attachments included).
+	maxMessageSize = 25 << 20
+	// searchDateLayout is the date format of the SEARCH SINCE criterion.
+	searchDateLayout = "02-Jan-2006"
+)
+
+// statusError is a NO or BAD completion of a command. The connection stays
+// usable after it, unlike transport errors.
+type statusError struct {
+	Command string
+	Status  string
+	Text    string
+}
+
+// errMessageUnavailable marks a message that cannot be downloaded while the
+// connection stays usable.
+var errMessageUnavailable = errors.New("message unavailable")
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("imap %s: %s %s", e.Command, e.Status, e.Text)
+}
+
+// response is one server response line. Literals ({n} followed by n bytes)
+// are cut out of the text and returned in order; literals above the size
+// limit are discarded and left nil.
+type response struct {
+	text     string
+	literals [][]byte
+}
+
+// folder is a mailbox returned by LIST.
+type folder struct {
+	Name       string
+	Delimiter  string
+	Selectable bool
+}
+
+// client is a minimal IMAP4rev1 client covering the read-only commands the
+// connector needs.
+type client struct {
+	conn net.Conn
+	r    *bufio.Reader
+	w    *bufio.Writer
+	tag  int
+}
+
+// dial connects to the server of cfg, upgrades the connection as configured
+// and logs in.
+func dial(ctx context.Context, cfg *Config) (*client, error) {
+	dialCtx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+	conn, err := utils.SSRFSafeDialContext(dialCtx, "tcp", cfg.address())
+	if err != nil {
+		return nil, fmt.Errorf("connect %s: %w", cfg.address(), err)
+	}
+	c := newConn(conn)
+	if cfg.Security == SecurityTLS {
+		if err := c.startTLS(dialCtx, cfg.Host); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if err := c.readGreeting(); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+	if cfg.Security == SecuritySTARTTLS {
+		if _, err := c.command(ctx, "STARTTLS"); err != nil {
+			c.conn.Close()
+			return nil, err
+		}
+		if err := c.startTLS(dialCtx, cfg.Host); err != nil {
+			c.conn.Close()
+			return nil, err
+		}
+	}
+	if _, err := c.command(ctx, "LOGIN "+quote(cfg.Username)+" "+quote(cfg.Password)); err != nil {
+		c.conn.Close()
+		var se *statusError
+		if errors.As(err, &se) {
+			return nil, fmt.Errorf("%w: login rejected: %s", datasource.ErrInvalidCredentials, se.Text)
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func newConn(conn net.Conn) *client {
+	return &client{conn: conn, r: bufio.NewReader(conn), w: bufio.NewWriter(conn)}
+}
+
+// startTLS wraps the connection in TLS.
+func (c *client) startTLS(ctx context.Context, host string) error {
+	tlsConn := tls.Client(c.conn, &tls.Config{ServerName: host, MinVersion: tls.VersionTLS12})
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		return fmt.Errorf("tls handshake: %w", err)
+	}
+	c.conn = tlsConn
+	c.r = bufio.NewReader(tlsConn)
+	c.w = bufio.NewWriter(tlsConn)
+	return nil
+}
+
+func (c *client) readGreeting() error {
+	_ = c.conn.SetDeadline(time.Now().Add(commandTimeout))
+	resp, err := c.readResponse()
+	if err != nil {
+		return fmt.Errorf("read greeting: %w", err)
+	}
+	if !strings.HasPrefix(resp.text, "* OK") && !strings.HasPrefix(resp.text, "* PREAUTH") {
+		return fmt.Errorf("unexpected greeting: %s", truncate(resp.text, 200))
+	}
+	return nil
+}
+
+// Logout ends the session and closes the connection.
+func (c *client) Logout() {
+	_, _ = c.command(context.Background(), "LOGOUT")
+	c.conn.Close()
+}
+
+// command sends a tagged command and returns its untagged responses.
+func (c *client) command(ctx context.Context, cmd string) ([]*response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.tag++
+	tag := fmt.Sprintf("A%03d", c.tag)
+	fields := strings.Fields(cmd)
+	name := fields[0]
+	if name == "UID" && len(fields) > 1 {
+		name += " " + fields[1]
+	}
+
+	_ = c.conn.SetDeadline(time.Now().Add(commandTimeout))
+	if _, err := c.w.WriteString(tag + " " + cmd + "\r\n"); err != nil {
+		return nil, fmt.Errorf("imap %s: %w", name, err)
+	}
+	if err := c.w.Flush(); err != nil {
+		return nil, fmt.Errorf("imap %s: %w", name, err)
+	}
+
+	var untagged []*response
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, fmt.Errorf("imap %s: %w", name, err)
+		}
+		if rest, ok := strings.CutPrefix(resp.text, tag+" "); ok {
+			status, text, _ := strings.Cut(rest, " ")
+			if strings.EqualFold(status, "OK") {
+				return untagged, nil
+			}
+			return nil, &statusError{Command: name, Status: strings.ToUpper(status), Text: text}
+		}
+		if strings.HasPrefix(resp.text, "+") {
+			return nil, fmt.Errorf("imap %s: unexpected continuation request", name)
+		}
+		untagged = append(untagged, resp)
+	}
+}
+
+// readResponse reads one response line, collecting any literals it carries.
+func (c *client) readResponse() (*response, error) {
+	resp := &response{}
+	var text strings.Builder
+	for {
+		line, err := c.r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		n, ok := literalSize(line)
+		if !ok {
+			text.WriteString(line)
+			resp.text = text.String()
+			return resp, nil
+		}
+		text.WriteString(line[:strings.LastIndexByte(line, '{')])
+		if n > maxMessageSize {
+			if _, err := io.CopyN(io.Discard, c.r, n); err != nil {
+				return nil, err
+			}
+			resp.literals = append(resp.literals, nil)
+			continue
+		}
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		resp.literals = append(resp.literals, buf)
+	}
+}
+
+// literalSize reports whether line ends with a literal announcement {n}.
+func literalSize(line string) (int64, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false
+	}
+	open := strings.LastIndexByte(line, '{')
+	if open < 0 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(line[open+1:len(line)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return n, true
+}
+
+// ListFolders returns all mailboxes of the account.
+func (c *client) ListFolders(ctx context.Context) ([]folder, error) {
+	resps, err := c.command(ctx, `LIST "" "*"`)
+	if err != nil {
+		return nil, err
+	}
+	var out []folder
+	for _, resp := range resps {
+		rest, ok := strings.CutPrefix(resp.text, "* LIST ")
+		if !ok {
+			continue
+		}
+		f, ok := parseListResponse(rest, resp.literals)
+		if ok {
+			out = append(out, f)
+		}
+	}
+	return out, nil
+}
+
+// parseListResponse parses `(attrs) delimiter name` of a LIST response.
+func parseListResponse(s string, literals [][]byte) (folder, bool) {
+	if !strings.HasPrefix(s, "(") {
+		return folder{}, false
+	}
+	end := strings.IndexByte(s, ')')
+	if end < 0 {
+		return folder{}, false
+	}
+	f := folder{Selectable: true}
+	for _, attr := range strings.Fields(s[1:end]) {
+		if strings.EqualFold(attr, `\Noselect`) || strings.EqualFold(attr, `\NonExistent`) {
+			f.Selectable = false
+		}
+	}
+	rest := strings.TrimLeft(s[end+1:], " ")
+	delim, rest, ok := readString(rest)
+	if !ok {
+		return folder{}, false
+	}
+	if delim != "NIL" {
+		f.Delimiter = delim
+	}
+	rest = strings.TrimLeft(rest, " ")
+	if rest == "" && len(literals) > 0 {
+		f.Name = string(literals[0])
+		return f, f.Name != ""
+	}
+	f.Name, _, ok = readString(rest)
+	return f, ok && f.Name != ""
+}
+
+// readString reads a quoted string or an atom from the start of s.
+func readString(s string) (string, string, bool) {
+	if s == "" {
+		return "", "", false
+	}
+	if s[0] != '"' {
+		end := strings.IndexByte(s, ' ')
+		if end < 0 {
+			return s, "", true
+		}
+		return s[:end], s[end:], true
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			return b.String(), s[i+1:], true
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return "", "", false
+}
+
+// Examine opens a folder read-only and returns its UIDVALIDITY.
+func (c *client) Examine(ctx context.Context, name string) (uint32, error) {
+	resps, err := c.command(ctx, "EXAMINE "+quote(name))
+	if err != nil {
+		return 0, err
+	}
+	for _, resp := range resps {
+		if _, code, ok := strings.Cut(resp.text, "[UIDVALIDITY "); ok {
+			v, _, _ := strings.Cut(code, "]")
+			n, err := strconv.ParseUint(v, 10, 32)
+			if err == nil {
+				return uint32(n), nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("imap EXAMINE %s: no UIDVALIDITY", name)
+}
+
+// SearchUIDs returns, in ascending order, the UIDs of the open folder that
+// are at least minUID and, when since is set, received on or after since.
+func (c *client) SearchUIDs(ctx context.Context, minUID uint32, since time.Time) ([]uint32, error) {
+	var criteria []string
+	if minUID > 1 {
+		criteria = append(criteria, fmt.Sprintf("UID %d:*", minUID))
+	}
+	if !since.IsZero() {
+		criteria = append(criteria, "SINCE "+since.Format(searchDateLayout))
+	}
+	if len(criteria) == 0 {
+		criteria = append(criteria, "ALL")
+	}
+	resps, err := c.command(ctx, "UID SEARCH "+strings.Join(criteria, " "))
+	if err != nil {
+		return nil, err
+	}
+	var uids []uint32
+	for _, resp := range resps {
+		rest, ok := strings.CutPrefix(resp.text, "* SEARCH")
+		if !ok {
+			continue
+		}
+		for _, f := range strings.Fields(rest) {
+			n, err := strconv.ParseUint(f, 10, 32)
+			// "UID n:*" always matches the last message, even below n.
+			if err == nil && uint32(n) >= minUID {
+				uids = append(uids, uint32(n))
+			}
+		}
+	}
+	sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+	return uids, nil
+}
+
+// FetchMessage downloads the raw RFC 5322 message with the given UID
+// without setting its \Seen flag.
+func (c *client) FetchMessage(ctx context.Context, uid uint32) ([]byte, error) {
+	resps, err := c.command(ctx, fmt.Sprintf("UID FETCH %d (UID BODY.PEEK[])", uid))
+	if err != nil {
+		return nil, err
+	}
+	for _, resp := range resps {
+		if !strings.Contains(resp.text, " FETCH ") || len(resp.literals) == 0 {
+			continue
+		}
+		if resp.literals[0] == nil {
+			return nil, fmt.Errorf("%w: exceeds %d bytes", errMessageUnavailable, maxMessageSize)
+		}
+		return resp.literals[0], nil
+	}
+	return nil, fmt.Errorf("%w: UID %d not found", errMessageUnavailable, uid)
+}
+
+// quote returns s as an IMAP quoted string.
+func quote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\r", "", "\n", "").Replace(s)
+	return `"` + s + `"`
+}
+
+// truncate returns s truncated to maxLen with "..." appended if longer.
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "..."
+}
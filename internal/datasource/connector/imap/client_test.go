@@ -0,0 +1,281 @@
+package imap
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/utils"
+)
+
+// TestMain whitelists loopback for SSRF so the fake IMAP server (127.0.0.1)
+// is reachable. Production keeps the default strict SSRF policy.
+func TestMain(m *testing.M) {
+	_ = os.Setenv("SSRF_WHITELIST", "127.0.0.1,::1")
+	utils.ResetSSRFWhitelistForTest()
+	os.Exit(m.Run())
+}
+
+type fakeMessage struct {
+	uid uint32
+	raw string
+}
+
+type fakeFolder struct {
+	uidValidity uint32
+	messages    []fakeMessage
+	noselect    bool
+}
+
+// fakeIMAP is a plaintext IMAP server implementing the commands used by the
+// client.
+type fakeIMAP struct {
+	ln       net.Listener
+	password string
+
+	mu         sync.Mutex
+	folders    map[string]*fakeFolder
+	commands   []string // commands received, without tags
+	failFetch  map[uint32]bool
+	dropOnUIDs map[uint32]bool // close the connection when fetching these
+}
+
+func newFakeIMAP(t *testing.T) *fakeIMAP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	f := &fakeIMAP{
+		ln:         ln,
+		password:   "secret",
+		folders:    map[string]*fakeFolder{"INBOX": {uidValidity: 1}},
+		failFetch:  make(map[uint32]bool),
+		dropOnUIDs: make(map[uint32]bool),
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return f
+}
+
+func (f *fakeIMAP) cfg() *Config {
+	return &Config{
+		Host:     "127.0.0.1",
+		Port:     f.ln.Addr().(*net.TCPAddr).Port,
+		Security: SecurityNone,
+		Username: "me@example.com",
+		Password: f.password,
+	}
+}
+
+// add appends a message to a folder, creating the folder if needed.
+func (f *fakeIMAP) add(folderName string, uid uint32, raw string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	fd := f.folders[folderName]
+	if fd == nil {
+		fd = &fakeFolder{uidValidity: 1}
+		f.folders[folderName] = fd
+	}
+	fd.messages = append(fd.messages, fakeMessage{uid: uid, raw: raw})
+}
+
+func (f *fakeIMAP) commandLog() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.commands...)
+}
+
+func (f *fakeIMAP) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	reply := func(lines ...string) {
+		for _, l := range lines {
+			w.WriteString(l + "\r\n")
+		}
+		w.Flush()
+	}
+	reply("* OK fake IMAP4rev1 ready")
+
+	var selected *fakeFolder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, cmd, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		f.mu.Lock()
+		f.commands = append(f.commands, cmd)
+		f.mu.Unlock()
+		verb, args, _ := strings.Cut(cmd, " ")
+
+		switch strings.ToUpper(verb) {
+		case "LOGIN":
+			_, rest, _ := readString(args)
+			pass, _, _ := readString(strings.TrimLeft(rest, " "))
+			if pass != f.password {
+				reply(tag + " NO [AUTHENTICATIONFAILED] Invalid credentials")
+				continue
+			}
+			reply(tag + " OK LOGIN completed")
+		case "LIST":
+			f.mu.Lock()
+			var lines []string
+			for name, fd := range f.folders {
+				attrs := `\HasNoChildren`
+				if fd.noselect {
+					attrs = `\Noselect \HasChildren`
+				}
+				lines = append(lines, fmt.Sprintf(`* LIST (%s) "/" %s`, attrs, quote(name)))
+			}
+			f.mu.Unlock()
+			reply(append(lines, tag+" OK LIST completed")...)
+		case "EXAMINE":
+			name, _, _ := readString(args)
+			f.mu.Lock()
+			selected = f.folders[name]
+			f.mu.Unlock()
+			if selected == nil {
+				reply(tag + " NO Mailbox does not exist")
+				continue
+			}
+			reply(fmt.Sprintf("* %d EXISTS", len(selected.messages)),
+				fmt.Sprintf("* OK [UIDVALIDITY %d] UIDs valid", selected.uidValidity),
+				tag+" OK [READ-ONLY] EXAMINE completed")
+		case "UID":
+			sub, rest, _ := strings.Cut(args, " ")
+			switch strings.ToUpper(sub) {
+			case "SEARCH":
+				reply("* SEARCH"+f.search(selected, rest), tag+" OK SEARCH completed")
+			case "FETCH":
+				uidStr, _, _ := strings.Cut(rest, " ")
+				uid, _ := strconv.ParseUint(uidStr, 10, 32)
+				f.mu.Lock()
+				drop, fail := f.dropOnUIDs[uint32(uid)], f.failFetch[uint32(uid)]
+				f.mu.Unlock()
+				if drop {
+					return
+				}
+				if fail {
+					reply(tag + " NO Message is corrupt")
+					continue
+				}
+				for i, m := range selected.messages {
+					if m.uid == uint32(uid) {
+						w.WriteString(fmt.Sprintf("* %d FETCH (UID %d BODY[] {%d}\r\n", i+1, m.uid, len(m.raw)))
+						w.WriteString(m.raw)
+						w.WriteString(")\r\n")
+					}
+				}
+				reply(tag + " OK FETCH completed")
+			default:
+				reply(tag + " BAD unsupported")
+			}
+		case "LOGOUT":
+			reply("* BYE", tag+" OK LOGOUT completed")
+			return
+		default:
+			reply(tag + " BAD unsupported")
+		}
+	}
+}
+
+// search evaluates "UID n:*" like a real server: the range always includes
+// the highest UID, even when it is below n.
+func (f *fakeIMAP) search(fd *fakeFolder, criteria string) string {
+	minUID := uint64(1)
+	if rest, ok := strings.CutPrefix(criteria, "UID "); ok {
+		from, _, _ := strings.Cut(rest, ":")
+		minUID, _ = strconv.ParseUint(from, 10, 32)
+	}
+	var b strings.Builder
+	for i, m := range fd.messages {
+		if uint64(m.uid) >= minUID || i == len(fd.messages)-1 {
+			b.WriteString(" " + strconv.FormatUint(uint64(m.uid), 10))
+		}
+	}
+	return b.String()
+}
+
+func TestClient_LoginRejectedWrapsInvalidCredentials(t *testing.T) {
+	f := newFakeIMAP(t)
+	cfg := f.cfg()
+	cfg.Password = "wrong"
+	if _, err := dial(context.Background(), cfg); !errors.Is(err, datasource.ErrInvalidCredentials) {
+		t.Errorf("err = %v, want ErrInvalidCredentials", err)
+	}
+}
+
+func TestClient_ListExamineSearchFetch(t *testing.T) {
+	f := newFakeIMAP(t)
+	f.add("INBOX", 3, "Subject: a\r\n\r\nA")
+	f.add("INBOX", 7, "Subject: b\r\n\r\nB")
+	f.folders["Archive"] = &fakeFolder{uidValidity: 9, noselect: true}
+
+	cli, err := dial(context.Background(), f.cfg())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer cli.Logout()
+
+	folders, err := cli.ListFolders(context.Background())
+	if err != nil || len(folders) != 2 {
+		t.Fatalf("ListFolders = %+v, %v", folders, err)
+	}
+	uv, err := cli.Examine(context.Background(), "INBOX")
+	if err != nil || uv != 1 {
+		t.Fatalf("Examine = %d, %v", uv, err)
+	}
+	uids, err := cli.SearchUIDs(context.Background(), 8, time.Time{})
+	if err != nil || len(uids) != 0 {
+		t.Errorf("SearchUIDs(8) = %v, %v; want none (the server echoes the last UID)", uids, err)
+	}
+	uids, err = cli.SearchUIDs(context.Background(), 1, time.Time{})
+	if err != nil || len(uids) != 2 || uids[1] != 7 {
+		t.Errorf("SearchUIDs(1) = %v, %v", uids, err)
+	}
+	raw, err := cli.FetchMessage(context.Background(), 7)
+	if err != nil || string(raw) != "Subject: b\r\n\r\nB" {
+		t.Errorf("FetchMessage = %q, %v", raw, err)
+	}
+	if log := f.commandLog(); !strings.Contains(strings.Join(log, "\n"), "UID FETCH 7 (UID BODY.PEEK[])") {
+		t.Errorf("commands = %v, want a BODY.PEEK fetch", log)
+	}
+}
+
+func TestParseListResponse(t *testing.T) {
+	tests := []struct {
+		in       string
+		literals [][]byte
+		want     folder
+	}{
+		{`(\HasNoChildren) "/" "INBOX"`, nil, folder{Name: "INBOX", Delimiter: "/", Selectable: true}},
+		{`(\Noselect) "." Public`, nil, folder{Name: "Public", Delimiter: ".", Selectable: false}},
+		{`() NIL "a \"b\""`, nil, folder{Name: `a "b"`, Selectable: true}},
+		{`() "/" `, [][]byte{[]byte("Lit/Name")}, folder{Name: "Lit/Name", Delimiter: "/", Selectable: true}},
+	}
+	for _, tt := range tests {
+		got, ok := parseListResponse(tt.in, tt.literals)
+		if !ok || got != tt.want {
+			t.Errorf("parseListResponse(%q) = %+v, %v; want %+v", tt.in, got, ok, tt.want)
+		}
+	}
+}
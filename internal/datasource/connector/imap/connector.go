@@ -0,0 +1,311 @@
+package imap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Compile-time proof that *Connector satisfies the datasource.Connector interface.
+var _ datasource.Connector = (*Connector)(nil)
+
+// Connector implements datasource.Connector for IMAP mailboxes.
+type Connector struct{}
+
+// NewConnector creates a new IMAP connector.
+func NewConnector() *Connector { return &Connector{} }
+
+// Type returns the connector type identifier.
+func (c *Connector) Type() string { return types.ConnectorTypeIMAP }
+
+// Validate verifies the server and credentials by logging in.
+func (c *Connector) Validate(ctx context.Context, config *types.DataSourceConfig) error {
+	cfg, err := parseConfig(config)
+	if err != nil {
+		return err
+	}
+	cli, err := dial(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf("imap connection failed: %w", err)
+	}
+	cli.Logout()
+	return nil
+}
+
+// ResolveResourceAncestors has nothing to do: folders are listed as a flat
+// list of full paths, so a selection has no ancestors to reveal.
+func (c *Connector) ResolveResourceAncestors(
+	ctx context.Context, config *types.DataSourceConfig, resourceIDs []string,
+) ([]string, error) {
+	return []string{}, nil
+}
+
+// ListResources returns the selectable folders of the mailbox. Resource IDs
+// are the folder names as sent by the server (modified UTF-7).
+func (c *Connector) ListResources(
+	ctx context.Context, config *types.DataSourceConfig, parentID string,
+) ([]types.Resource, error) {
+	if parentID != "" {
+		return []types.Resource{}, nil
+	}
+	cfg, err := parseConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := dial(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer cli.Logout()
+	folders, err := cli.ListFolders(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list folders: %w", err)
+	}
+
+	out := make([]types.Resource, 0, len(folders))
+	for _, f := range folders {
+		if !f.Selectable {
+			continue
+		}
+		out = append(out, types.Resource{
+			ExternalID: f.Name,
+			Name:       decodeFolderName(f.Name),
+			Type:       "folder",
+		})
+	}
+	// INBOX first, then alphabetical.
+	sort.Slice(out, func(i, j int) bool {
+		if inbox := strings.EqualFold(out[i].ExternalID, defaultFolder); inbox != strings.EqualFold(out[j].ExternalID, defaultFolder) {
+			return inbox
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// FetchAll downloads the messages of the selected folders (INBOX when
+// resourceIDs is empty), limited by SinceDays.
+func (c *Connector) FetchAll(ctx context.Context, config *types.DataSourceConfig, resourceIDs []string) ([]types.FetchedItem, error) {
+	items, _, err := c.walk(ctx, config, resourceIDs, nil)
+	return items, err
+}
+
+// FetchIncremental downloads the messages received since the prior cursor:
+// UIDs above the last synced UID of each folder. Folders without prior
+// state, or whose UIDVALIDITY changed, are synced as on a first sync.
+func (c *Connector) FetchIncremental(
+	ctx context.Context,
+	config *types.DataSourceConfig,
+	cursor *types.SyncCursor,
+) ([]types.FetchedItem, *types.SyncCursor, error) {
+	prev := &imapCursor{}
+	if cursor != nil && cursor.ConnectorCursor != nil {
+		b, _ := json.Marshal(cursor.ConnectorCursor)
+		_ = json.Unmarshal(b, prev)
+	}
+
+	items, newCursor, err := c.walk(ctx, config, config.ResourceIDs, prev)
+	if newCursor == nil {
+		return nil, nil, err
+	}
+
+	cursorMap := make(map[string]interface{})
+	b, _ := json.Marshal(newCursor)
+	_ = json.Unmarshal(b, &cursorMap)
+	return items, &types.SyncCursor{
+		LastSyncTime:    newCursor.LastSyncTime,
+		ConnectorCursor: cursorMap,
+	}, err
+}
+
+// walk is the shared implementation for FetchAll / FetchIncremental. prev is
+// nil for a full sync, in which case no cursor is returned.
+//
+// A message that cannot be downloaded or parsed yields a placeholder item
+// once: such failures (too large, expunged, malformed) do not heal, so the
+// folder position moves past it. A folder that cannot be opened is reported
+// through PartialFetchError; a broken connection ends the sync, keeping the
+// progress made so far.
+func (c *Connector) walk(
+	ctx context.Context,
+	config *types.DataSourceConfig,
+	resourceIDs []string,
+	prev *imapCursor,
+) ([]types.FetchedItem, *imapCursor, error) {
+	cfg, err := parseConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	folders := resourceIDs
+	if len(folders) == 0 {
+		folders = []string{defaultFolder}
+	}
+
+	cli, err := dial(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cli.Logout()
+
+	newCursor := &imapCursor{LastSyncTime: time.Now().UTC(), Folders: make(map[string]folderState)}
+	seen := make(map[string]bool)
+	var out []types.FetchedItem
+	var folderErrors []string
+
+	for i, name := range folders {
+		var state folderState
+		if prev != nil {
+			state = prev.Folders[name]
+		}
+		items, next, err := c.syncFolder(ctx, cli, cfg, name, state, seen)
+		out = append(out, items...)
+		newCursor.Folders[name] = next
+		if err == nil {
+			continue
+		}
+		logger.Warnf(ctx, "[IMAP] sync folder %s failed: %v", name, err)
+		folderErrors = append(folderErrors, fmt.Sprintf("%s: %v", decodeFolderName(name), err))
+		var se *statusError
+		if !errors.As(err, &se) {
+			// The connection is unusable; keep the state of the remaining folders.
+			for _, rest := range folders[i+1:] {
+				if prev != nil {
+					newCursor.Folders[rest] = prev.Folders[rest]
+				}
+			}
+			break
+		}
+	}
+
+	var resultErr error
+	if len(folderErrors) > 0 {
+		if len(out) == 0 && len(folderErrors) == len(folders) {
+			resultErr = fmt.Errorf("all folders failed: %s", strings.Join(folderErrors, "; "))
+		} else {
+			resultErr = &datasource.PartialFetchError{Details: folderErrors}
+		}
+	}
+	if prev == nil {
+		return out, nil, resultErr
+	}
+	return out, newCursor, resultErr
+}
+
+// syncFolder downloads the new messages of one folder, returning them with
+// the folder's next state.
+func (c *Connector) syncFolder(
+	ctx context.Context,
+	cli *client,
+	cfg *Config,
+	name string,
+	state folderState,
+	seen map[string]bool,
+) ([]types.FetchedItem, folderState, error) {
+	uidValidity, err := cli.Examine(ctx, name)
+	if err != nil {
+		return nil, state, err
+	}
+	if uidValidity != state.UIDValidity {
+		if state.UIDValidity != 0 {
+			logger.Infof(ctx, "[IMAP] folder %s: UIDVALIDITY changed, resyncing", name)
+		}
+		state = folderState{UIDValidity: uidValidity}
+	}
+
+	var since time.Time
+	if state.LastUID == 0 && cfg.SinceDays > 0 {
+		since = time.Now().AddDate(0, 0, -cfg.SinceDays)
+	}
+	uids, err := cli.SearchUIDs(ctx, state.LastUID+1, since)
+	if err != nil {
+		return nil, state, err
+	}
+
+	var out []types.FetchedItem
+	for _, uid := range uids {
+		item, err := c.fetchMessage(ctx, cli, cfg, name, uidValidity, uid)
+		if err != nil {
+			return out, state, err
+		}
+		if !seen[item.ExternalID] {
+			seen[item.ExternalID] = true
+			out = append(out, item)
+		}
+		state.LastUID = uid
+	}
+	logger.Infof(ctx, "[IMAP] folder %s: new=%d fetched=%d", name, len(uids), len(out))
+	return out, state, nil
+}
+
+// fetchMessage downloads one message and converts it into a FetchedItem.
+// Messages that are too large or cannot be parsed produce a placeholder item
+// carrying the error in its metadata; transport errors are returned.
+func (c *Connector) fetchMessage(
+	ctx context.Context, cli *client, cfg *Config, folderName string, uidValidity, uid uint32,
+) (types.FetchedItem, error) {
+	placeholder := func(err error) types.FetchedItem {
+		return types.FetchedItem{
+			ExternalID:       messageExternalID(cfg.Username, "", folderName, uidValidity, uid),
+			Title:            fmt.Sprintf("%s #%d", decodeFolderName(folderName), uid),
+			SourceResourceID: folderName,
+			Metadata: map[string]string{
+				"error":   err.Error(),
+				"channel": types.ChannelIMAP,
+				"folder":  folderName,
+			},
+		}
+	}
+
+	raw, err := cli.FetchMessage(ctx, uid)
+	if err != nil {
+		var se *statusError
+		if errors.As(err, &se) || errors.Is(err, errMessageUnavailable) {
+			return placeholder(err), nil
+		}
+		return types.FetchedItem{}, err
+	}
+	msg, err := parseMessage(raw)
+	if err != nil {
+		return placeholder(err), nil
+	}
+
+	metadata := map[string]string{
+		"channel":    types.ChannelIMAP,
+		"folder":     folderName,
+		"message_id": msg.MessageID,
+		"subject":    msg.Subject,
+		"from":       msg.From,
+		"to":         msg.To,
+	}
+	if msg.Cc != "" {
+		metadata["cc"] = msg.Cc
+	}
+	if !msg.Date.IsZero() {
+		metadata["date"] = msg.Date.UTC().Format(time.RFC3339)
+	}
+	if len(msg.Attachments) > 0 {
+		metadata["attachments"] = strings.Join(msg.Attachments, ", ")
+	}
+	updatedAt := msg.Date
+	if updatedAt.IsZero() {
+		updatedAt = time.Now().UTC()
+	}
+	return types.FetchedItem{
+		ExternalID:       messageExternalID(cfg.Username, msg.MessageID, folderName, uidValidity, uid),
+		Title:            msg.title(),
+		Content:          []byte(msg.Markdown()),
+		ContentType:      "text/markdown",
+		FileName:         sanitizeFileName(msg.title()) + ".md",
+		UpdatedAt:        updatedAt,
+		SourceResourceID: folderName,
+		Metadata:         metadata,
+	}, nil
+}
@@ -0,0 +1,194 @@
+package imap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func makeDSConfig(f *fakeIMAP, resourceIDs []string) *types.DataSourceConfig {
+	cfg := f.cfg()
+	return &types.DataSourceConfig{
+		Type: types.ConnectorTypeIMAP,
+		Credentials: map[string]interface{}{
+			"username": cfg.Username,
+			"password": cfg.Password,
+		},
+		Settings: map[string]interface{}{
+			"host":     cfg.Host,
+			"port":     fmt.Sprint(cfg.Port),
+			"security": cfg.Security,
+		},
+		ResourceIDs: resourceIDs,
+	}
+}
+
+func rawMail(id, subject, body string) string {
+	return "Message-ID: <" + id + ">\r\n" +
+		"From: Alice <alice@example.com>\r\n" +
+		"To: me@example.com\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Date: Wed, 01 May 2024 12:00:00 +0000\r\n" +
+		"\r\n" + body + "\r\n"
+}
+
+func TestConnector_Type(t *testing.T) {
+	if NewConnector().Type() != types.ConnectorTypeIMAP {
+		t.Errorf("Type() = %q, want %q", NewConnector().Type(), types.ConnectorTypeIMAP)
+	}
+}
+
+func TestConnector_ListResources_Folders(t *testing.T) {
+	f := newFakeIMAP(t)
+	f.add("Archive", 1, rawMail("a@x", "a", "a"))
+	f.add("&UXZO1mWHTvZZOQ-", 1, rawMail("b@x", "b", "b"))
+	f.folders["[Gmail]"] = &fakeFolder{noselect: true}
+
+	resources, err := NewConnector().ListResources(context.Background(), makeDSConfig(f, nil), "")
+	if err != nil {
+		t.Fatalf("ListResources: %v", err)
+	}
+	var names []string
+	for _, r := range resources {
+		names = append(names, r.ExternalID+"="+r.Name)
+	}
+	if strings.Join(names, ",") != "INBOX=INBOX,Archive=Archive,&UXZO1mWHTvZZOQ-=其他文件夹" {
+		t.Errorf("resources = %v", names)
+	}
+}
+
+func TestConnector_FetchAll_MarkdownWithMetadata(t *testing.T) {
+	f := newFakeIMAP(t)
+	f.add("INBOX", 1, rawMail("one@example.com", "Weekly report", "All green."))
+
+	items, err := NewConnector().FetchAll(context.Background(), makeDSConfig(f, nil), nil)
+	if err != nil {
+		t.Fatalf("FetchAll: %v", err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("items = %d, want 1", len(items))
+	}
+	it := items[0]
+	if it.ExternalID != "me@example.com:one@example.com" || it.Title != "Weekly report" ||
+		it.FileName != "Weekly report.md" || it.SourceResourceID != "INBOX" {
+		t.Errorf("item = %+v", it)
+	}
+	if got := string(it.Content); !strings.HasPrefix(got, "# Weekly report\n") ||
+		!strings.Contains(got, "- **From:** Alice <alice@example.com>") || !strings.Contains(got, "All green.") {
+		t.Errorf("content = %q", got)
+	}
+	want := map[string]string{
+		"channel":    types.ChannelIMAP,
+		"message_id": "one@example.com",
+		"from":       "Alice <alice@example.com>",
+		"date":       "2024-05-01T12:00:00Z",
+		"folder":     "INBOX",
+	}
+	for k, v := range want {
+		if it.Metadata[k] != v {
+			t.Errorf("metadata[%s] = %q, want %q", k, it.Metadata[k], v)
+		}
+	}
+}
+
+func TestConnector_FetchIncremental_NewMessagesOnly(t *testing.T) {
+	f := newFakeIMAP(t)
+	f.add("INBOX", 1, rawMail("1@x", "one", "1"))
+	f.add("INBOX", 2, rawMail("2@x", "two", "2"))
+	conn := NewConnector()
+	config := makeDSConfig(f, nil)
+
+	items, cursor, err := conn.FetchIncremental(context.Background(), config, nil)
+	if err != nil || len(items) != 2 {
+		t.Fatalf("first sync = %d items, %v", len(items), err)
+	}
+
+	items, cursor, err = conn.FetchIncremental(context.Background(), config, cursor)
+	if err != nil || len(items) != 0 {
+		t.Fatalf("second sync = %+v, %v; want nothing new", items, err)
+	}
+
+	f.add("INBOX", 5, rawMail("5@x", "five", "5"))
+	items, cursor, err = conn.FetchIncremental(context.Background(), config, cursor)
+	if err != nil || len(items) != 1 || items[0].Title != "five" {
+		t.Fatalf("third sync = %+v, %v; want message 5", items, err)
+	}
+	state := cursor.ConnectorCursor["folders"].(map[string]interface{})["INBOX"].(map[string]interface{})
+	if state["last_uid"] != float64(5) || state["uid_validity"] != float64(1) {
+		t.Errorf("cursor = %v", state)
+	}
+
+	// A new UIDVALIDITY invalidates the UIDs: the folder is synced again.
+	f.folders["INBOX"].uidValidity = 2
+	items, _, err = conn.FetchIncremental(context.Background(), config, cursor)
+	if err != nil || len(items) != 3 {
+		t.Errorf("after UIDVALIDITY change = %d items, %v; want 3", len(items), err)
+	}
+}
+
+func TestConnector_FetchAll_DedupesByMessageID(t *testing.T) {
+	f := newFakeIMAP(t)
+	f.add("INBOX", 1, rawMail("same@x", "filed twice", "body"))
+	f.add("Archive", 4, rawMail("same@x", "filed twice", "body"))
+	f.add("Archive", 5, rawMail("other@x", "other", "body"))
+
+	items, err := NewConnector().FetchAll(context.Background(), makeDSConfig(f, nil), []string{"INBOX", "Archive"})
+	if err != nil {
+		t.Fatalf("FetchAll: %v", err)
+	}
+	if len(items) != 2 || items[0].ExternalID != "me@example.com:same@x" || items[1].ExternalID != "me@example.com:other@x" {
+		t.Errorf("items = %+v, want one entry per Message-ID", items)
+	}
+}
+
+func TestConnector_FetchIncremental_FailedMessagePlaceholder(t *testing.T) {
+	f := newFakeIMAP(t)
+	f.add("INBOX", 1, rawMail("1@x", "one", "1"))
+	f.add("INBOX", 2, rawMail("2@x", "two", "2"))
+	f.failFetch[1] = true
+
+	items, cursor, err := NewConnector().FetchIncremental(context.Background(), makeDSConfig(f, nil), nil)
+	if err != nil {
+		t.Fatalf("FetchIncremental: %v", err)
+	}
+	if len(items) != 2 || items[0].Metadata["error"] == "" || items[1].Title != "two" {
+		t.Fatalf("items = %+v, want a placeholder then message 2", items)
+	}
+	state := cursor.ConnectorCursor["folders"].(map[string]interface{})["INBOX"].(map[string]interface{})
+	if state["last_uid"] != float64(2) {
+		t.Errorf("cursor = %v, want the position past the failed message", state)
+	}
+}
+
+func TestConnector_FetchIncremental_ConnectionLostKeepsProgress(t *testing.T) {
+	f := newFakeIMAP(t)
+	f.add("INBOX", 1, rawMail("1@x", "one", "1"))
+	f.add("INBOX", 2, rawMail("2@x", "two", "2"))
+	f.add("Archive", 1, rawMail("3@x", "three", "3"))
+	f.dropOnUIDs[2] = true
+
+	prev := &types.SyncCursor{ConnectorCursor: map[string]interface{}{
+		"folders": map[string]interface{}{"Archive": map[string]interface{}{"uid_validity": 1, "last_uid": 0}},
+	}}
+	items, cursor, err := NewConnector().FetchIncremental(context.Background(),
+		makeDSConfig(f, []string{"INBOX", "Archive"}), prev)
+	var partial *datasource.PartialFetchError
+	if !errors.As(err, &partial) {
+		t.Fatalf("err = %v, want PartialFetchError", err)
+	}
+	if len(items) != 1 || items[0].Title != "one" {
+		t.Errorf("items = %+v, want message 1 only", items)
+	}
+	folders := cursor.ConnectorCursor["folders"].(map[string]interface{})
+	if folders["INBOX"].(map[string]interface{})["last_uid"] != float64(1) {
+		t.Errorf("INBOX state = %v, want last_uid 1", folders["INBOX"])
+	}
+	if _, ok := folders["Archive"]; !ok {
+		t.Errorf("Archive state should be kept, got %v", folders)
+	}
+}
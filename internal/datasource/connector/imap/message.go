@@ -0,0 +1,238 @@
+package imap
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	htmltomd "github.com/JohannesKaufmann/html-to-markdown/v2"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+const (
+	// maxPartSize caps the decoded size of a single text part.
+	maxPartSize = 5 << 20
+	// maxPartDepth bounds the nesting of multipart bodies.
+	maxPartDepth = 10
+)
+
+// wordDecoder decodes RFC 2047 encoded words in any charset known to the
+// WHATWG encoding index (GBK, Big5, Shift_JIS, ...).
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// charsetReader converts input from charset to UTF-8.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// message is a parsed email.
+type message struct {
+	MessageID   string
+	Subject     string
+	From        string
+	To          string
+	Cc          string
+	Date        time.Time
+	Body        string // Markdown
+	Attachments []string
+}
+
+// parseMessage parses a raw RFC 5322 message.
+func parseMessage(raw []byte) (*message, error) {
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("parse message: %w", err)
+	}
+	msg := &message{
+		MessageID: strings.Trim(strings.TrimSpace(m.Header.Get("Message-Id")), "<>"),
+		Subject:   decodeHeader(m.Header.Get("Subject")),
+		From:      decodeAddresses(m.Header.Get("From")),
+		To:        decodeAddresses(m.Header.Get("To")),
+		Cc:        decodeAddresses(m.Header.Get("Cc")),
+	}
+	if date, err := m.Header.Date(); err == nil {
+		msg.Date = date
+	}
+
+	var parts bodyParts
+	walkPart(textproto.MIMEHeader(m.Header), m.Body, &parts, 0)
+	msg.Attachments = parts.attachments
+	switch {
+	case strings.TrimSpace(parts.plain.String()) != "":
+		msg.Body = strings.TrimSpace(parts.plain.String())
+	case strings.TrimSpace(parts.html.String()) != "":
+		html := parts.html.String()
+		if md, err := htmltomd.ConvertString(html); err == nil && strings.TrimSpace(md) != "" {
+			msg.Body = strings.TrimSpace(md)
+		} else {
+			msg.Body = strings.TrimSpace(html)
+		}
+	}
+	return msg, nil
+}
+
+// Markdown renders the message as a Markdown document: the subject as the
+// title, a list of the sender, recipients and date, then the body.
+func (m *message) Markdown() string {
+	var b strings.Builder
+	b.WriteString("# " + m.title() + "\n\n")
+	for _, field := range []struct{ name, value string }{
+		{"From", m.From},
+		{"To", m.To},
+		{"Cc", m.Cc},
+		{"Date", m.dateString()},
+		{"Attachments", strings.Join(m.Attachments, ", ")},
+	} {
+		if field.value != "" {
+			b.WriteString("- **" + field.name + ":** " + field.value + "\n")
+		}
+	}
+	if m.Body != "" {
+		b.WriteString("\n" + m.Body + "\n")
+	}
+	return b.String()
+}
+
+func (m *message) title() string {
+	if s := strings.TrimSpace(m.Subject); s != "" {
+		return s
+	}
+	return "(no subject)"
+}
+
+func (m *message) dateString() string {
+	if m.Date.IsZero() {
+		return ""
+	}
+	return m.Date.Format(time.RFC1123Z)
+}
+
+// bodyParts collects the text parts and attachment names of a message.
+type bodyParts struct {
+	plain       strings.Builder
+	html        strings.Builder
+	attachments []string
+}
+
+// walkPart visits a MIME part, recursing into multipart bodies.
+func walkPart(header textproto.MIMEHeader, body io.Reader, out *bodyParts, depth int) {
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+	disposition, dispParams, _ := mime.ParseMediaType(header.Get("Content-Disposition"))
+	fileName := decodeHeader(firstNonEmpty(dispParams["filename"], params["name"]))
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth >= maxPartDepth || params["boundary"] == "" {
+			return
+		}
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err != nil {
+				return
+			}
+			walkPart(p.Header, p, out, depth+1)
+		}
+	}
+	if disposition == "attachment" || fileName != "" || mediaType == "message/rfc822" {
+		out.attachments = append(out.attachments, firstNonEmpty(fileName, "unnamed"))
+		return
+	}
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return
+	}
+
+	r := decodeTransfer(header.Get("Content-Transfer-Encoding"), body)
+	if charset := strings.ToLower(params["charset"]); charset != "" && charset != "utf-8" && charset != "us-ascii" {
+		if cr, err := charsetReader(charset, r); err == nil {
+			r = cr
+		}
+	}
+	data, err := io.ReadAll(io.LimitReader(r, maxPartSize))
+	if err != nil && len(data) == 0 {
+		return
+	}
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	if mediaType == "text/plain" {
+		appendPart(&out.plain, text)
+	} else {
+		appendPart(&out.html, text)
+	}
+}
+
+func appendPart(b *strings.Builder, text string) {
+	if b.Len() > 0 {
+		b.WriteString("\n\n")
+	}
+	b.WriteString(text)
+}
+
+// decodeTransfer undoes the Content-Transfer-Encoding of a part body.
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	default:
+		return body
+	}
+}
+
+// decodeHeader decodes RFC 2047 encoded words, returning the raw value on
+// failure.
+func decodeHeader(v string) string {
+	if v == "" {
+		return ""
+	}
+	decoded, err := wordDecoder.DecodeHeader(v)
+	if err != nil {
+		return strings.TrimSpace(v)
+	}
+	return strings.TrimSpace(decoded)
+}
+
+// decodeAddresses formats an address list header as "Name <addr>, ...".
+func decodeAddresses(v string) string {
+	if strings.TrimSpace(v) == "" {
+		return ""
+	}
+	parser := mail.AddressParser{WordDecoder: wordDecoder}
+	list, err := parser.ParseList(v)
+	if err != nil {
+		return decodeHeader(v)
+	}
+	out := make([]string, 0, len(list))
+	for _, a := range list {
+		if a.Name != "" {
+			out = append(out, a.Name+" <"+a.Address+">")
+		} else {
+			out = append(out, a.Address)
+		}
+	}
+	return strings.Join(out, ", ")
+}
+
+// firstNonEmpty returns the first non-empty trimmed string among the args.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package imap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMessage_MultipartPrefersPlainText(t *testing.T) {
+	raw := strings.Join([]string{
+		"Message-ID: <abc@example.com>",
+		"From: =?UTF-8?B?5byg5LiJ?= <zhang@example.com>",
+		"To: team@example.com, Bob <bob@example.com>",
+		"Subject: =?UTF-8?Q?Q3_=E8=AE=A1=E5=88=92?=",
+		"Date: Wed, 01 May 2024 12:00:00 +0800",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="outer"`,
+		"",
+		"--outer",
+		`Content-Type: multipart/alternative; boundary="inner"`,
+		"",
+		"--inner",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"Hello =E4=BD=A0=E5=A5=BD",
+		"--inner",
+		"Content-Type: text/html; charset=utf-8",
+		"",
+		"<p>Hello <b>HTML</b></p>",
+		"--inner--",
+		"--outer",
+		`Content-Type: application/pdf; name="plan.pdf"`,
+		"Content-Disposition: attachment; filename=\"plan.pdf\"",
+		"Content-Transfer-Encoding: base64",
+		"",
+		"JVBERg==",
+		"--outer--",
+		"",
+	}, "\r\n")
+
+	msg, err := parseMessage([]byte(raw))
+	if err != nil {
+		t.Fatalf("parseMessage: %v", err)
+	}
+	if msg.MessageID != "abc@example.com" || msg.Subject != "Q3 计划" {
+		t.Errorf("id/subject = %q / %q", msg.MessageID, msg.Subject)
+	}
+	if msg.From != "张三 <zhang@example.com>" || msg.To != "team@example.com, Bob <bob@example.com>" {
+		t.Errorf("from/to = %q / %q", msg.From, msg.To)
+	}
+	if msg.Body != "Hello 你好" {
+		t.Errorf("body = %q, want the text/plain part", msg.Body)
+	}
+	if len(msg.Attachments) != 1 || msg.Attachments[0] != "plan.pdf" {
+		t.Errorf("attachments = %v", msg.Attachments)
+	}
+
+	md := msg.Markdown()
+	for _, want := range []string{"# Q3 计划\n", "- **From:** 张三 <zhang@example.com>\n",
+		"- **Date:** Wed, 01 May 2024 12:00:00 +0800\n", "- **Attachments:** plan.pdf\n", "\nHello 你好\n"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestParseMessage_HTMLOnlyInGBK(t *testing.T) {
+	// "<p>会议纪要</p>" in GBK, base64-encoded.
+	raw := "Subject: =?GBK?B?u+HS6bzN0qo=?=\r\n" +
+		"Content-Type: text/html; charset=gbk\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"PHA+u+HS6bzN0qo8L3A+\r\n"
+
+	msg, err := parseMessage([]byte(raw))
+	if err != nil {
+		t.Fatalf("parseMessage: %v", err)
+	}
+	if msg.Subject != "会议纪要" {
+		t.Errorf("subject = %q", msg.Subject)
+	}
+	if msg.Body != "会议纪要" {
+		t.Errorf("body = %q, want HTML converted to Markdown", msg.Body)
+	}
+}
+
+func TestDecodeFolderName(t *testing.T) {
+	for in, want := range map[string]string{
+		"INBOX":            "INBOX",
+		"&UXZO1mWHTvZZOQ-": "其他文件夹",
+		"Sent &- Archive":  "Sent & Archive",
+		"&ZeVnLIqe-/2024":  "日本語/2024",
+		"&broken":          "&broken",
+	} {
+		if got := decodeFolderName(in); got != want {
+			t.Errorf("decodeFolderName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
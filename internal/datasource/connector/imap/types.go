@@ -0,0 +1,241 @@
+// Package imap implements the email (IMAP) data source connector for WeKnora.
+//
+// It polls the selected folders of an IMAP mailbox and ingests every
+// message as a Markdown document: the subject as the title, followed by the
+// sender, recipients and date, then the message body (text/plain preferred,
+// HTML converted to Markdown). Folders are the selectable resources; an
+// empty selection syncs INBOX.
+//
+// Capabilities:
+//   - Incremental: the cursor holds the highest synced UID of each folder,
+//     so each sync only downloads newer messages. A changed UIDVALIDITY
+//     resyncs the folder.
+//   - Dedupe: knowledge is keyed by the Message-ID, so a message filed in
+//     several folders, or downloaded again, maps to a single entry.
+//     Deletions are NOT synced — mail is routinely archived or expunged.
+//
+// Connections go through the SSRF-safe dialer, and messages are opened
+// read-only (EXAMINE, BODY.PEEK) so their \Seen flags are left untouched.
+package imap
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Connection security modes.
+const (
+	SecurityTLS      = "tls"      // implicit TLS, port 993
+	SecuritySTARTTLS = "starttls" // STARTTLS upgrade, port 143
+	SecurityNone     = "none"     // plaintext, for trusted networks only
+)
+
+// defaultFolder is synced when no folder is selected.
+const defaultFolder = "INBOX"
+
+// Config holds the IMAP configuration.
+//
+// Host, Port, Security and SinceDays are stored in DataSourceConfig.Settings
+// (non-secret, editable without replacing credentials); Username and
+// Password live in Credentials so they are encrypted at rest.
+type Config struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"`
+	Security string `json:"security,omitempty"`
+	// SinceDays limits the first sync of a folder to messages received in
+	// the last N days; 0 syncs the whole folder.
+	SinceDays int `json:"since_days,omitempty"`
+
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// settingKeys are the configuration keys read from Settings.
+var settingKeys = []string{"host", "port", "security", "since_days"}
+
+// parseConfig extracts and validates the IMAP configuration.
+func parseConfig(config *types.DataSourceConfig) (*Config, error) {
+	if config == nil {
+		return nil, fmt.Errorf("%w: config is nil", datasource.ErrInvalidConfig)
+	}
+	merged := make(map[string]interface{}, len(config.Credentials)+len(settingKeys))
+	for k, v := range config.Credentials {
+		merged[k] = v
+	}
+	for _, k := range settingKeys {
+		if v, ok := config.Settings[k]; ok && v != "" && v != nil {
+			merged[k] = v
+		}
+	}
+	// The settings form may send numbers as strings.
+	for _, k := range []string{"port", "since_days"} {
+		if s, ok := merged[k].(string); ok {
+			n, err := strconv.Atoi(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s must be a number", datasource.ErrInvalidConfig, k)
+			}
+			merged[k] = n
+		}
+	}
+	b, err := json.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("marshal config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return nil, fmt.Errorf("parse imap config: %w", err)
+	}
+
+	cfg.Host = strings.TrimSpace(cfg.Host)
+	cfg.Security = strings.ToLower(strings.TrimSpace(cfg.Security))
+	if cfg.Security == "" {
+		cfg.Security = SecurityTLS
+	}
+	switch cfg.Security {
+	case SecurityTLS, SecuritySTARTTLS, SecurityNone:
+	default:
+		return nil, fmt.Errorf("%w: unsupported security %q", datasource.ErrInvalidConfig, cfg.Security)
+	}
+	if cfg.Host == "" || strings.ContainsAny(cfg.Host, "/: ") {
+		return nil, fmt.Errorf("%w: host must be a host name without scheme or port", datasource.ErrInvalidConfig)
+	}
+	if cfg.Port < 0 || cfg.Port > 65535 || cfg.SinceDays < 0 {
+		return nil, fmt.Errorf("%w: invalid port or since_days", datasource.ErrInvalidConfig)
+	}
+	if strings.TrimSpace(cfg.Username) == "" || cfg.Password == "" {
+		return nil, fmt.Errorf("%w: username and password are required", datasource.ErrInvalidCredentials)
+	}
+	return &cfg, nil
+}
+
+// address returns host:port, defaulting the port from the security mode.
+func (c *Config) address() string {
+	port := c.Port
+	if port == 0 {
+		port = 993
+		if c.Security != SecurityTLS {
+			port = 143
+		}
+	}
+	return c.Host + ":" + strconv.Itoa(port)
+}
+
+// imapCursor stores incremental sync state per folder.
+type imapCursor struct {
+	LastSyncTime time.Time              `json:"last_sync_time"`
+	Folders      map[string]folderState `json:"folders"`
+}
+
+// folderState is the sync position in one folder. UIDs are only comparable
+// within the same UIDVALIDITY.
+type folderState struct {
+	UIDValidity uint32 `json:"uid_validity"`
+	LastUID     uint32 `json:"last_uid"`
+}
+
+// messageExternalID keys a message by its Message-ID within the account so
+// copies in several folders dedupe to one knowledge entry. Messages without
+// a Message-ID fall back to their folder position.
+func messageExternalID(username, messageID, folder string, uidValidity, uid uint32) string {
+	if messageID != "" {
+		return username + ":" + messageID
+	}
+	return fmt.Sprintf("%s:%s/%d/%d", username, folder, uidValidity, uid)
+}
+
+// decodeFolderName decodes an IMAP modified UTF-7 mailbox name (RFC 3501
+// section 5.1.3) for display, returning the input unchanged if malformed.
+func decodeFolderName(name string) string {
+	if !strings.Contains(name, "&") {
+		return name
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] != '&' {
+			b.WriteByte(name[i])
+			continue
+		}
+		end := strings.IndexByte(name[i:], '-')
+		if end < 0 {
+			return name
+		}
+		enc := name[i+1 : i+end]
+		i += end
+		if enc == "" {
+			b.WriteByte('&')
+			continue
+		}
+		raw, ok := decodeModifiedBase64(enc)
+		if !ok || len(raw)%2 != 0 {
+			return name
+		}
+		units := make([]uint16, len(raw)/2)
+		for j := range units {
+			units[j] = uint16(raw[2*j])<<8 | uint16(raw[2*j+1])
+		}
+		b.WriteString(string(utf16.Decode(units)))
+	}
+	if !utf8.ValidString(b.String()) {
+		return name
+	}
+	return b.String()
+}
+
+// decodeModifiedBase64 decodes unpadded base64 using "," in place of "/".
+func decodeModifiedBase64(s string) ([]byte, bool) {
+	const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+,"
+	var out []byte
+	var acc uint32
+	bits := 0
+	for i := 0; i < len(s); i++ {
+		v := strings.IndexByte(alphabet, s[i])
+		if v < 0 {
+			return nil, false
+		}
+		acc = acc<<6 | uint32(v)
+		bits += 6
+		if bits >= 8 {
+			bits -= 8
+			out = append(out, byte(acc>>bits))
+		}
+	}
+	return out, true
+}
+
+// sanitizeFileName removes characters invalid in filenames and truncates to a
+// safe length at a UTF-8 rune boundary (mirrors the RSS connector).
+func sanitizeFileName(name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "untitled"
+	}
+	replacer := strings.NewReplacer(
+		"/", "_", "\\", "_", ":", "_", "*", "_",
+		"?", "_", "\"", "_", "<", "_", ">", "_", "|", "_",
+		"\n", " ", "\r", " ", "\t", " ",
+	)
+	result := strings.TrimSpace(replacer.Replace(name))
+	if result == "" {
+		return "untitled"
+	}
+	const maxBytes = 200
+	if len(result) > maxBytes {
+		result = result[:maxBytes]
+		for len(result) > 0 {
+			r, size := utf8.DecodeLastRuneInString(result)
+			if r != utf8.RuneError || size != 1 {
+				break
+			}
+			result = result[:len(result)-1]
+		}
+	}
+	return result
+}
@@ -0,0 +1,47 @@
+package imap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/datasource"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestParseConfig(t *testing.T) {
+	creds := map[string]interface{}{"username": "me@example.com", "password": "pw"}
+	tests := []struct {
+		name     string
+		settings map[string]interface{}
+		creds    map[string]interface{}
+		wantErr  error
+		wantAddr string
+	}{
+		{"tls default port", map[string]interface{}{"host": "imap.example.com"}, creds, nil, "imap.example.com:993"},
+		{"starttls", map[string]interface{}{"host": "imap.example.com", "security": "STARTTLS"}, creds, nil, "imap.example.com:143"},
+		{"explicit port as string", map[string]interface{}{"host": "imap.example.com", "port": "1993"}, creds, nil, "imap.example.com:1993"},
+		{"missing host", map[string]interface{}{}, creds, datasource.ErrInvalidConfig, ""},
+		{"host with scheme", map[string]interface{}{"host": "imaps://imap.example.com"}, creds, datasource.ErrInvalidConfig, ""},
+		{"bad security", map[string]interface{}{"host": "imap.example.com", "security": "ssl3"}, creds, datasource.ErrInvalidConfig, ""},
+		{"bad port", map[string]interface{}{"host": "imap.example.com", "port": "abc"}, creds, datasource.ErrInvalidConfig, ""},
+		{"missing password", map[string]interface{}{"host": "imap.example.com"},
+			map[string]interface{}{"username": "me"}, datasource.ErrInvalidCredentials, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := parseConfig(&types.DataSourceConfig{Credentials: tt.creds, Settings: tt.settings})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if cfg.address() != tt.wantAddr {
+				t.Errorf("address() = %q, want %q", cfg.address(), tt.wantAddr)
+			}
+		})
+	}
+}
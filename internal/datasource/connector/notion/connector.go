@@ -132,7 +132,7 @@ func (c *Connector) FetchAll(ctx context.Context, config *types.DataSourceConfig
 	}
 
 	client := newClient(notionCfg.APIKey, extractBaseURL(config))
-	visited := c.excludedSetFromListResources(ctx, config, resourceIDs)
+	visited, tree := c.excludedSetFromListResources(ctx, config, resourceIDs)
 	var allItems []types.FetchedItem
 
 	for _, resourceID := range resourceIDs {
@@ -151,6 +151,7 @@ func (c *Connector) FetchAll(ctx context.Context, config *types.DataSourceConfig
 		allItems = append(allItems, items...)
 	}
 
+	tree.annotate(allItems)
 	return allItems, nil
 }
 
@@ -208,7 +209,7 @@ func (c *Connector) FetchIncremental(ctx context.Context, config *types.DataSour
 
 	// Subsequent syncs: discover all pages, diff against cursor, fetch only changes
 	logger.Infof(ctx, "[Notion] incremental sync, discovering pages")
-	pages, fetchVisited := c.discoverAllResources(ctx, client, resourceIDs)
+	pages, fetchVisited, tree := c.discoverAllResources(ctx, client, resourceIDs)
 	logger.Infof(ctx, "[Notion] discovered %d pages", len(pages))
 
 	newEditTimes := make(map[string]time.Time)
@@ -249,6 +250,8 @@ func (c *Connector) FetchIncremental(ctx context.Context, config *types.DataSour
 		}
 	}
 
+	tree.annotate(changedItems)
+
 	// Detect deletions. Only emit IsDeleted for pages that previously belonged
 	// to a selected resource and have actually disappeared at source. Pages that
 	// are still visible but no longer reachable from any selected root (i.e. the
@@ -659,12 +662,12 @@ func (c *Connector) buildDatabaseItem(ctx context.Context, client *notionClient,
 // then filters by parent chain via BFS from the selected resource IDs.
 // Returns the included pages plus the excluded set (visible pages NOT under any
 // selected root) so callers can seed `visited` for child-block recursion without
-// a second SearchPages round-trip.
-func (c *Connector) discoverAllResources(ctx context.Context, client *notionClient, resourceIDs []string) (included []notionPage, excluded map[string]bool) {
+// a second SearchPages round-trip, and the workspace page tree.
+func (c *Connector) discoverAllResources(ctx context.Context, client *notionClient, resourceIDs []string) (included []notionPage, excluded map[string]bool, tree pageTree) {
 	allPages, err := client.SearchPages(ctx)
 	if err != nil {
 		logger.Warnf(ctx, "[Notion] failed to search pages for discovery: %v", err)
-		return nil, nil
+		return nil, nil, pageTree{}
 	}
 
 	// data_source objects use database_parent for workspace hierarchy.
@@ -676,11 +679,13 @@ func (c *Connector) discoverAllResources(ctx context.Context, client *notionClie
 	}
 
 	childrenOf := make(map[string][]string)
+	tree = newPageTree()
 	for _, p := range allPages {
 		if p.InTrash {
 			continue
 		}
 		parentID := resolveParentID(p, allIDs)
+		tree.add(p.ID, parentID, p.Title)
 		if parentID != "" {
 			childrenOf[parentID] = append(childrenOf[parentID], p.ID)
 		}
@@ -716,7 +721,7 @@ func (c *Connector) discoverAllResources(ctx context.Context, client *notionClie
 			excluded[id] = true
 		}
 	}
-	return included, excluded
+	return included, excluded, tree
 }
 
 // --- File upload resolution ---
@@ -918,21 +923,85 @@ func computeExcludedSet(visibleIDs []string, parentOf map[string]string, selecte
 }
 
 // excludedSetFromListResources fetches the picker hierarchy via ListResources
-// and computes the deselected set. Used by FetchAll where no other code path
-// already has the page list in hand.
-func (c *Connector) excludedSetFromListResources(ctx context.Context, config *types.DataSourceConfig, selectedIDs []string) map[string]bool {
+// and computes the deselected set, also returning the page tree. Used by
+// FetchAll where no other code path already has the page list in hand.
+func (c *Connector) excludedSetFromListResources(ctx context.Context, config *types.DataSourceConfig, selectedIDs []string) (map[string]bool, pageTree) {
 	visible, err := c.ListResources(ctx, config, "")
 	if err != nil {
 		logger.Warnf(ctx, "[Notion] failed to list visible resources for exclusion: %v", err)
-		return map[string]bool{}
+		return map[string]bool{}, pageTree{}
 	}
 	ids := make([]string, 0, len(visible))
-	parentOf := make(map[string]string, len(visible))
+	tree := newPageTree()
 	for _, r := range visible {
 		ids = append(ids, r.ExternalID)
-		parentOf[r.ExternalID] = r.ParentID
+		tree.add(r.ExternalID, r.ParentID, r.Name)
+	}
+	return computeExcludedSet(ids, tree.parentOf, selectedIDs), tree
+}
+
+// pageTree is the workspace hierarchy of the pages and databases visible to
+// the integration, used to record where each synced item sits.
+type pageTree struct {
+	parentOf map[string]string
+	titleOf  map[string]string
+}
+
+func newPageTree() pageTree {
+	return pageTree{parentOf: make(map[string]string), titleOf: make(map[string]string)}
+}
+
+func (t pageTree) add(id, parentID, title string) {
+	t.parentOf[id] = parentID
+	t.titleOf[id] = title
+}
+
+// path returns the titles of id's ancestors, root first, joined by " / ".
+func (t pageTree) path(id string) string {
+	var titles []string
+	seen := map[string]bool{id: true}
+	for cur := t.parentOf[id]; cur != "" && !seen[cur]; cur = t.parentOf[cur] {
+		seen[cur] = true
+		titles = append(titles, t.titleOf[cur])
+	}
+	for i, j := 0, len(titles)-1; i < j; i, j = i+1, j-1 {
+		titles[i], titles[j] = titles[j], titles[i]
+	}
+	return strings.Join(titles, " / ")
+}
+
+// annotate records the position of each item in the page tree in its
+// metadata: parent_id (the enclosing page or database) and path (the
+// ancestor titles). Attachments sit under the page they were found on.
+func (t pageTree) annotate(items []types.FetchedItem) {
+	for i := range items {
+		item := &items[i]
+		if item.IsDeleted {
+			continue
+		}
+		id := item.ExternalID
+		if item.Metadata["object_type"] == objectTypeAttachment {
+			id = item.SourceResourceID
+			if _, ok := t.titleOf[id]; !ok {
+				continue
+			}
+			if item.Metadata == nil {
+				item.Metadata = make(map[string]string)
+			}
+			item.Metadata["parent_id"] = id
+			item.Metadata["path"] = strings.TrimPrefix(t.path(id)+" / "+t.titleOf[id], " / ")
+			continue
+		}
+		parentID := t.parentOf[id]
+		if parentID == "" {
+			continue
+		}
+		if item.Metadata == nil {
+			item.Metadata = make(map[string]string)
+		}
+		item.Metadata["parent_id"] = parentID
+		item.Metadata["path"] = t.path(id)
 	}
-	return computeExcludedSet(ids, parentOf, selectedIDs)
 }
 
 // --- Helpers ---
@@ -285,3 +285,31 @@ func TestPropertyToString(t *testing.T) {
 		})
 	}
 }
+
+func TestPageTreeAnnotate(t *testing.T) {
+	tree := newPageTree()
+	tree.add("root", "", "Engineering")
+	tree.add("db", "root", "Runbooks")
+	tree.add("rec", "db", "Deploy")
+
+	items := []types.FetchedItem{
+		{ExternalID: "root", Metadata: map[string]string{"object_type": objectTypePage}},
+		{ExternalID: "rec", Metadata: map[string]string{"object_type": objectTypePage}},
+		{ExternalID: "rec:spec.pdf", SourceResourceID: "rec", Metadata: map[string]string{"object_type": objectTypeAttachment}},
+		{ExternalID: "gone", IsDeleted: true},
+	}
+	tree.annotate(items)
+
+	if _, ok := items[0].Metadata["parent_id"]; ok {
+		t.Errorf("root should have no parent_id, got %v", items[0].Metadata)
+	}
+	if items[1].Metadata["parent_id"] != "db" || items[1].Metadata["path"] != "Engineering / Runbooks" {
+		t.Errorf("record metadata = %v", items[1].Metadata)
+	}
+	if items[2].Metadata["parent_id"] != "rec" || items[2].Metadata["path"] != "Engineering / Runbooks / Deploy" {
+		t.Errorf("attachment metadata = %v", items[2].Metadata)
+	}
+	if items[3].Metadata != nil {
+		t.Errorf("deleted item should not be annotated, got %v", items[3].Metadata)
+	}
+}
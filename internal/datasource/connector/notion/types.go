@@ -27,22 +27,32 @@ const DefaultBaseURL = "https://api.notion.com"
 
 // Config holds Notion-specific configuration for the data source connector.
 type Config struct {
-	APIKey string `json:"api_key"` // Internal Integration Token
+	// APIKey is the bearer token: an internal integration token, or the
+	// access token of a public (OAuth) integration.
+	APIKey string `json:"api_key"`
 }
 
 // parseNotionConfig extracts and validates Notion config from DataSourceConfig.
+// The token is read from "api_key" (internal integration) or, failing that,
+// "access_token" (OAuth public integration); Notion accepts both as Bearer.
 func parseNotionConfig(config *types.DataSourceConfig) (*Config, error) {
 	if config == nil {
 		return nil, datasource.ErrInvalidConfig
 	}
 
-	tokenVal, ok := config.Credentials["api_key"]
+	key := "api_key"
+	if _, ok := config.Credentials[key]; !ok {
+		if _, ok := config.Credentials["access_token"]; ok {
+			key = "access_token"
+		}
+	}
+	tokenVal, ok := config.Credentials[key]
 	if !ok {
 		return nil, fmt.Errorf("%w: missing api_key", datasource.ErrInvalidCredentials)
 	}
 	token, ok := tokenVal.(string)
 	if !ok || token == "" {
-		return nil, fmt.Errorf("%w: api_key must be a non-empty string", datasource.ErrInvalidCredentials)
+		return nil, fmt.Errorf("%w: %s must be a non-empty string", datasource.ErrInvalidCredentials, key)
 	}
 
 	return &Config{APIKey: token}, nil
@@ -23,6 +23,20 @@ func TestParseNotionConfig(t *testing.T) {
 		}
 	})
 
+	t.Run("oauth access token", func(t *testing.T) {
+		cfg, err := parseNotionConfig(&types.DataSourceConfig{
+			Credentials: map[string]interface{}{
+				"access_token": "ntn_oauth456",
+			},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if cfg.APIKey != "ntn_oauth456" {
+			t.Errorf("APIKey = %q, want %q", cfg.APIKey, "ntn_oauth456")
+		}
+	})
+
 	t.Run("nil config", func(t *testing.T) {
 		_, err := parseNotionConfig(nil)
 		if err == nil {
@@ -232,14 +232,18 @@ func (c *Connector) walk(
 		}
 
 		var kept, skipped int
+		// Feeds occasionally repeat an entry (republished posts, merged
+		// pages); the first occurrence of a GUID wins.
+		seen := make(map[string]bool, len(feed.Items))
 		for _, item := range feed.Items {
 			if item == nil {
 				continue
 			}
 			itemID := firstNonEmpty(item.GUID, item.Link, item.Title)
-			if itemID == "" {
+			if itemID == "" || seen[itemID] {
 				continue
 			}
+			seen[itemID] = true
 
 			feedContent := firstNonEmpty(item.Content, item.Description)
 			feedSig := feedSignalFingerprint(item, feedContent)
@@ -329,6 +333,18 @@ func (c *Connector) resolveItem(
 		author = item.Author.Name
 	}
 
+	metadata := map[string]string{
+		"channel":    types.ChannelRSS,
+		"feed_url":   feedURL,
+		"feed_title": feed.Title,
+		"guid":       item.GUID,
+		"link":       item.Link,
+		"author":     author,
+	}
+	if item.PublishedParsed != nil && !item.PublishedParsed.IsZero() {
+		metadata["published_at"] = item.PublishedParsed.UTC().Format(time.RFC3339)
+	}
+
 	return resolvedFeedItem{
 		fingerprint: contentFingerprint(content),
 		item: types.FetchedItem{
@@ -340,14 +356,7 @@ func (c *Connector) resolveItem(
 			URL:              item.Link,
 			UpdatedAt:        updatedAt,
 			SourceResourceID: feedURL,
-			Metadata: map[string]string{
-				"channel":    types.ChannelRSS,
-				"feed_url":   feedURL,
-				"feed_title": feed.Title,
-				"guid":       item.GUID,
-				"link":       item.Link,
-				"author":     author,
-			},
+			Metadata:         metadata,
 		},
 	}
 }
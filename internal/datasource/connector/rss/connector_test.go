@@ -43,6 +43,7 @@ type fakeFeed struct {
 	articleAuthHeaders []string
 	articleFetches     atomic.Int32
 	failFeed           atomic.Bool
+	repeatFirst        bool // serve guid-1 a second time at the end
 }
 
 func newFakeFeed(t *testing.T) *fakeFeed {
@@ -75,6 +76,10 @@ func newFakeFeed(t *testing.T) *fakeFeed {
 		w.Header().Set("Content-Type", "application/rss+xml")
 		base := "http://" + r.Host
 		desc := "summary fallback"
+		repeat := ""
+		if f.repeatFirst {
+			repeat = "<item><title>Article One (again)</title><guid>guid-1</guid></item>"
+		}
 		fmt.Fprintf(w, `<?xml version="1.0"?>
 <rss version="2.0"><channel>
 <title>%s</title>
@@ -94,7 +99,8 @@ func newFakeFeed(t *testing.T) *fakeFeed {
   <pubDate>Tue, 03 Jan 2006 15:04:05 GMT</pubDate>
   <description>%s</description>
 </item>
-</channel></rss>`, f.feedTitle, base, base, desc, base, desc)
+%s
+</channel></rss>`, f.feedTitle, base, base, desc, base, desc, repeat)
 	})
 
 	f.server = httptest.NewServer(mux)
@@ -292,6 +298,24 @@ func TestConnector_FetchAll_FullTextMarkdown(t *testing.T) {
 	if !strings.HasSuffix(it.FileName, ".md") {
 		t.Errorf("FileName = %q, want .md suffix", it.FileName)
 	}
+	if it.Metadata["published_at"] != "2006-01-02T15:04:05Z" {
+		t.Errorf("published_at = %q, want 2006-01-02T15:04:05Z", it.Metadata["published_at"])
+	}
+}
+
+func TestConnector_FetchAll_DedupesRepeatedGUID(t *testing.T) {
+	f := newFakeFeed(t)
+	f.repeatFirst = true
+	items, err := NewConnector().FetchAll(context.Background(), makeConfig(f.feedURL(), ""), nil)
+	if err != nil {
+		t.Fatalf("FetchAll error: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(items))
+	}
+	if items[0].Title != "Article One" {
+		t.Errorf("first item = %q, want the first occurrence of guid-1", items[0].Title)
+	}
 }
 
 func TestConnector_FetchIncremental_SkipsWithoutArticleFetch(t *testing.T) {
@@ -16,6 +16,8 @@
 //     entry is unchanged; content fingerprints detect feed-body changes. Article-
 //     only edits without feed updates may be missed until the feed entry changes.
 //     Deletions are NOT synced — feeds routinely drop old items.
+//   - Dedupe: items are keyed by GUID (falling back to link, then title); an
+//     entry repeated within a feed is ingested once.
 //
 // All outbound requests go through the SSRF-safe HTTP client so a malicious
 // feed cannot redirect WeKnora to internal services.
@@ -33,6 +33,10 @@ var (
 
 	// Sync log errors
 	ErrSyncLogNotFound = errors.New("sync log not found")
+
+	// Webhook errors
+	ErrWebhookNotSupported = errors.New("connector does not support webhooks")
+	ErrWebhookUnauthorized = errors.New("webhook signature verification failed")
 )
 
 // PartialFetchError indicates that some resources were fetched successfully but
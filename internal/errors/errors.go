@@ -29,6 +29,9 @@ const (
 	ErrTenantInactive      ErrorCode = 2002
 	ErrTenantNameRequired  ErrorCode = 2003
 	ErrTenantInvalidStatus ErrorCode = 2004
+	ErrTenantTokenBudget   ErrorCode = 2005
+	ErrTenantRateLimited   ErrorCode = 2006
+	ErrTenantStorageQuota  ErrorCode = 2007
 
 	// Agent related error codes (2100-2199)
 	ErrAgentMissingThinkingModel ErrorCode = 2100
@@ -43,6 +46,9 @@ const (
 	ErrVectorStoreBindingInvalid ErrorCode = 2200
 	ErrVectorStoreUnavailable    ErrorCode = 2201
 
+	// Model related error codes (2300-2399)
+	ErrStructuredOutputInvalid ErrorCode = 2300
+
 	// Add more error codes here
 )
 
@@ -184,6 +190,50 @@ func NewTenantInactiveError() *AppError {
 	}
 }
 
+// NewTenantTokenBudgetExceededError creates an error for a tenant that has
+// used up its daily or monthly token budget
+func NewTenantTokenBudgetExceededError() *AppError {
+	return &AppError{
+		Code:     ErrTenantTokenBudget,
+		Message:  "租户 token 预算已用尽",
+		HTTPCode: http.StatusTooManyRequests,
+	}
+}
+
+// NewTenantRateLimitedError creates an error for a tenant that hit one of
+// its API limits. limit names the limit (e.g. "requests_per_minute"); the
+// details carry the limit, its maximum and, when known, the seconds to wait.
+func NewTenantRateLimitedError(limit string, max int, retryAfterSeconds int) *AppError {
+	details := map[string]any{
+		"limit": limit,
+		"max":   max,
+	}
+	if retryAfterSeconds > 0 {
+		details["retry_after_seconds"] = retryAfterSeconds
+	}
+	return &AppError{
+		Code:     ErrTenantRateLimited,
+		Message:  "请求过于频繁，已达到租户限额",
+		Details:  details,
+		HTTPCode: http.StatusTooManyRequests,
+	}
+}
+
+// NewTenantStorageQuotaExceededError creates an error for a tenant whose
+// storage quota cannot hold more data
+func NewTenantStorageQuotaExceededError(used, quota int64) *AppError {
+	return &AppError{
+		Code:    ErrTenantStorageQuota,
+		Message: "租户存储空间已用尽",
+		Details: map[string]any{
+			"limit":         "storage_bytes",
+			"storage_used":  used,
+			"storage_quota": quota,
+		},
+		HTTPCode: http.StatusTooManyRequests,
+	}
+}
+
 // Agent related errors
 func NewAgentMissingThinkingModelError() *AppError {
 	return &AppError{
@@ -248,6 +298,21 @@ func NewVectorStoreUnavailableError(message string) *AppError {
 	}
 }
 
+// NewStructuredOutputInvalidError creates an error for a model answer that
+// still did not match the requested JSON schema after the repair attempts
+func NewStructuredOutputInvalidError(attempts int, problem, content string) *AppError {
+	return &AppError{
+		Code:    ErrStructuredOutputInvalid,
+		Message: "模型输出不符合 JSON Schema",
+		Details: map[string]any{
+			"attempts": attempts,
+			"error":    problem,
+			"content":  content,
+		},
+		HTTPCode: http.StatusUnprocessableEntity,
+	}
+}
+
 // IsAppError checks if the error is an AppError type
 func IsAppError(err error) (*AppError, bool) {
 	appErr, ok := err.(*AppError)
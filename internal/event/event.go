@@ -49,12 +49,16 @@ const (
 	EventAgentComplete EventType = "agent.complete" // Agent 完成
 
 	// Agent streaming events (for real-time feedback)
-	EventAgentThought     EventType = "thought"      // Agent 思考过程
-	EventAgentToolCall    EventType = "tool_call"    // 工具调用通知
-	EventAgentToolResult  EventType = "tool_result"  // 工具结果
-	EventAgentReflection  EventType = "reflection"   // Agent 反思
-	EventAgentReferences  EventType = "references"   // 知识引用
-	EventAgentFinalAnswer EventType = "final_answer" // 最终答案
+	EventAgentThought      EventType = "thought"      // Agent 思考过程
+	EventAgentToolCall     EventType = "tool_call"    // 工具调用通知
+	EventAgentToolResult   EventType = "tool_result"  // 工具结果
+	EventAgentReflection   EventType = "reflection"   // Agent 反思
+	EventAgentReferences   EventType = "references"   // 知识引用
+	EventAgentCitations    EventType = "citations"    // 答案中的引用标注
+	EventAgentVerification EventType = "verification" // 答案校验结果
+	EventAgentFollowUps    EventType = "follow_ups"   // 推荐追问
+	EventAgentDebugTrace   EventType = "debug_trace"  // 调试追踪
+	EventAgentFinalAnswer  EventType = "final_answer" // 最终答案
 
 	// MCP tool human approval (issue #1173)
 	EventToolApprovalRequired EventType = "tool_approval_required"
@@ -74,7 +78,8 @@ const (
 	EventSessionTitle EventType = "session_title" // 会话标题更新
 
 	// Control events
-	EventStop EventType = "stop" // 停止对话生成
+	EventStop      EventType = "stop"      // 停止对话生成
+	EventCancelled EventType = "cancelled" // 对话生成已取消
 )
 
 // Event represents an event in the system
@@ -1,5 +1,7 @@
 package event
 
+import "github.com/Tencent/WeKnora/internal/types"
+
 // EventData contains common event data structures for different stages
 
 // QueryData represents query-related event data
@@ -182,11 +184,36 @@ type AgentReferencesData struct {
 	Iteration  int         `json:"iteration"`
 }
 
+// AgentCitationsData represents the sources cited inline in an answer
+type AgentCitationsData struct {
+	Citations types.Citations `json:"citations"`
+}
+
+// AgentVerificationData represents the verification of an answer against
+// the retrieved context
+type AgentVerificationData struct {
+	Verification *types.AnswerVerification `json:"verification"`
+}
+
+// AgentFollowUpsData represents the follow-up questions suggested after an
+// answer
+type AgentFollowUpsData struct {
+	Questions []string `json:"questions"`
+}
+
+// AgentDebugTraceData represents the stage trace of a debug-traced
+// knowledge QA run
+type AgentDebugTraceData struct {
+	Trace *types.PipelineTrace `json:"trace"`
+}
+
 // AgentFinalAnswerData represents final answer streaming data
 type AgentFinalAnswerData struct {
 	Content    string `json:"content"`
 	Done       bool   `json:"done"`
 	IsFallback bool   `json:"is_fallback,omitempty"` // True when response is a fallback (no knowledge base match)
+	// Metadata names the chat model generating the answer, when known
+	Metadata *types.MessageMetadata `json:"metadata,omitempty"`
 }
 
 // AgentReflectionData represents agent reflection data
@@ -210,6 +237,14 @@ type StopData struct {
 	Reason    string `json:"reason,omitempty"` // Optional reason for stopping
 }
 
+// CancelledData is emitted once a stop has cancelled the generation and the
+// partial answer has been saved to the assistant message
+type CancelledData struct {
+	SessionID string `json:"session_id"`
+	MessageID string `json:"message_id"`
+	Reason    string `json:"reason,omitempty"`
+}
+
 // ToolApprovalRequiredData is emitted when an MCP tool marked dangerous is about to run.
 type ToolApprovalRequiredData struct {
 	PendingID          string      `json:"pending_id"`
@@ -0,0 +1,215 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// AnalyticsHandler serves the query analytics of the admin dashboard
+type AnalyticsHandler struct {
+	analyticsService interfaces.AnalyticsService
+}
+
+// NewAnalyticsHandler creates a new AnalyticsHandler.
+func NewAnalyticsHandler(analyticsService interfaces.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService}
+}
+
+// analyticsQuery reads the date range, knowledge base filter and limit of
+// the analytics endpoints
+func analyticsQuery(c *gin.Context) (*types.AnalyticsQuery, error) {
+	query := &types.AnalyticsQuery{
+		StartDay:        secutils.SanitizeForLog(c.Query("start_date")),
+		EndDay:          secutils.SanitizeForLog(c.Query("end_date")),
+		KnowledgeBaseID: secutils.SanitizeForLog(c.Query("knowledge_base_id")),
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, apperrors.NewBadRequestError("limit 必须是整数")
+		}
+		query.Limit = n
+	}
+	return query, nil
+}
+
+// GetOverview godoc
+// @Summary      获取问答概览
+// @Description  汇总当前租户的提问数、无结果提问数与回答耗时分位数，并按天给出提问数；日期为 UTC，默认最近 30 天
+// @Tags         问答分析
+// @Produce      json
+// @Param        start_date         query     string                        false  "开始日期（YYYY-MM-DD）"
+// @Param        end_date           query     string                        false  "结束日期（YYYY-MM-DD，含当天）"
+// @Param        knowledge_base_id  query     string                        false  "只统计检索了该知识库的提问"
+// @Success      200                {object}  types.QueryAnalyticsOverview  "问答概览"
+// @Failure      400                {object}  errors.AppError               "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /analytics/overview [get]
+func (h *AnalyticsHandler) GetOverview(c *gin.Context) {
+	ctx := c.Request.Context()
+	query, err := analyticsQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	data, err := h.analyticsService.GetOverview(ctx, query)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get query analytics overview: %v", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// TopQueries godoc
+// @Summary      获取热门提问
+// @Description  按提问次数列出最常见的问题，大小写、标点与空格不同的相同问题合并统计
+// @Tags         问答分析
+// @Produce      json
+// @Param        start_date         query     string             false  "开始日期（YYYY-MM-DD）"
+// @Param        end_date           query     string             false  "结束日期（YYYY-MM-DD，含当天）"
+// @Param        knowledge_base_id  query     string             false  "只统计检索了该知识库的提问"
+// @Param        limit              query     int                false  "返回数量，默认 20，最多 100"
+// @Success      200                {array}   types.QueryStat    "热门提问"
+// @Failure      400                {object}  errors.AppError    "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /analytics/top-queries [get]
+func (h *AnalyticsHandler) TopQueries(c *gin.Context) {
+	ctx := c.Request.Context()
+	query, err := analyticsQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	data, err := h.analyticsService.TopQueries(ctx, query)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get top queries: %v", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// ZeroResultQueries godoc
+// @Summary      获取无结果提问
+// @Description  列出检索了知识但没有分块达到检索阈值的提问，按次数排序，用于发现知识库缺失的内容
+// @Tags         问答分析
+// @Produce      json
+// @Param        start_date         query     string             false  "开始日期（YYYY-MM-DD）"
+// @Param        end_date           query     string             false  "结束日期（YYYY-MM-DD，含当天）"
+// @Param        knowledge_base_id  query     string             false  "只统计检索了该知识库的提问"
+// @Param        limit              query     int                false  "返回数量，默认 20，最多 100"
+// @Success      200                {array}   types.QueryStat    "无结果提问"
+// @Failure      400                {object}  errors.AppError    "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /analytics/zero-result-queries [get]
+func (h *AnalyticsHandler) ZeroResultQueries(c *gin.Context) {
+	ctx := c.Request.Context()
+	query, err := analyticsQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	data, err := h.analyticsService.ZeroResultQueries(ctx, query)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get zero-result queries: %v", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// TopDocuments godoc
+// @Summary      获取最常引用的文档
+// @Description  按回答中的引用次数、再按被检索次数列出文档
+// @Tags         问答分析
+// @Produce      json
+// @Param        start_date         query     string              false  "开始日期（YYYY-MM-DD）"
+// @Param        end_date           query     string              false  "结束日期（YYYY-MM-DD，含当天）"
+// @Param        knowledge_base_id  query     string              false  "只统计该知识库的文档"
+// @Param        limit              query     int                 false  "返回数量，默认 20，最多 100"
+// @Success      200                {array}   types.DocumentStat  "文档引用统计"
+// @Failure      400                {object}  errors.AppError     "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /analytics/documents [get]
+func (h *AnalyticsHandler) TopDocuments(c *gin.Context) {
+	ctx := c.Request.Context()
+	query, err := analyticsQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	data, err := h.analyticsService.TopDocuments(ctx, query)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get top documents: %v", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// KnowledgeBaseUsage godoc
+// @Summary      获取知识库使用情况
+// @Description  按知识库和天统计检索了该知识库的提问数与无结果提问数，使用最多的知识库在前
+// @Tags         问答分析
+// @Produce      json
+// @Param        start_date         query     string                     false  "开始日期（YYYY-MM-DD）"
+// @Param        end_date           query     string                     false  "结束日期（YYYY-MM-DD，含当天）"
+// @Param        knowledge_base_id  query     string                     false  "只统计该知识库"
+// @Success      200                {array}   types.KnowledgeBaseUsage   "知识库使用情况"
+// @Failure      400                {object}  errors.AppError            "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /analytics/knowledge-bases [get]
+func (h *AnalyticsHandler) KnowledgeBaseUsage(c *gin.Context) {
+	ctx := c.Request.Context()
+	query, err := analyticsQuery(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	data, err := h.analyticsService.KnowledgeBaseUsage(ctx, query)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get knowledge base usage: %v", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// APIKeyHandler manages the API keys of a tenant. Only tenant owners reach
+// it; the route-level guards check the role.
+type APIKeyHandler struct {
+	apiKeyService interfaces.APIKeyService
+}
+
+// NewAPIKeyHandler creates a new APIKeyHandler.
+func NewAPIKeyHandler(apiKeyService interfaces.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// ListAPIKeys godoc
+// @Summary      获取 API Key 列表
+// @Description  获取当前租户的 API Key；不返回密钥本身
+// @Tags         API Key
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "API Key 列表"
+// @Security     Bearer
+// @Router       /api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	keys, err := h.apiKeyService.ListAPIKeys(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    keys,
+	})
+}
+
+// CreateAPIKey godoc
+// @Summary      创建 API Key
+// @Description  创建带 scope、角色、知识库范围和过期时间的 API Key；密钥只在响应中出现这一次
+// @Tags         API Key
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.APIKeyRequest     true  "API Key 设置"
+// @Success      200      {object}  map[string]interface{}  "创建的 API Key 及密钥"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Router       /api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to bind API key payload", err)
+		c.Error(apperrors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	key, err := h.apiKeyService.CreateAPIKey(ctx, &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"name": secutils.SanitizeForLog(req.Name),
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    key,
+	})
+}
+
+// UpdateAPIKey godoc
+// @Summary      更新 API Key
+// @Description  替换 API Key 的名称、scope、角色、知识库范围和过期时间；密钥不变
+// @Tags         API Key
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "API Key ID"
+// @Param        request  body      types.APIKeyRequest     true  "API Key 设置"
+// @Success      200      {object}  map[string]interface{}  "更新后的 API Key"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "API Key 不存在"
+// @Security     Bearer
+// @Router       /api-keys/{id} [put]
+func (h *APIKeyHandler) UpdateAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to bind API key payload", err)
+		c.Error(apperrors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	key, err := h.apiKeyService.UpdateAPIKey(ctx, id, &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"api_key_id": id,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    key,
+	})
+}
+
+// RotateAPIKey godoc
+// @Summary      轮换 API Key
+// @Description  为 API Key 生成新密钥，旧密钥立即失效；新密钥只在响应中出现这一次
+// @Tags         API Key
+// @Produce      json
+// @Param        id   path      string                  true  "API Key ID"
+// @Success      200  {object}  map[string]interface{}  "API Key 及新密钥"
+// @Failure      404  {object}  errors.AppError         "API Key 不存在"
+// @Security     Bearer
+// @Router       /api-keys/{id}/rotate [post]
+func (h *APIKeyHandler) RotateAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+
+	key, err := h.apiKeyService.RotateAPIKey(ctx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"api_key_id": id,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    key,
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary      吊销 API Key
+// @Tags         API Key
+// @Produce      json
+// @Param        id   path      string                  true  "API Key ID"
+// @Success      200  {object}  map[string]interface{}  "吊销成功"
+// @Failure      404  {object}  errors.AppError         "API Key 不存在"
+// @Security     Bearer
+// @Router       /api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+
+	if err := h.apiKeyService.RevokeAPIKey(ctx, id); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"api_key_id": id,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// AutoTagHandler exposes re-tagging of knowledge base documents. KB access
+// is checked by the route-level KBAccessWrite guard.
+type AutoTagHandler struct {
+	autoTagService interfaces.AutoTagService
+}
+
+// NewAutoTagHandler creates a new AutoTagHandler.
+func NewAutoTagHandler(autoTagService interfaces.AutoTagService) *AutoTagHandler {
+	return &AutoTagHandler{autoTagService: autoTagService}
+}
+
+// RetagKnowledgeBase godoc
+// @Summary      自动打标签
+// @Description  异步按知识库的标签体系对文档重新分类并写入自动标签；手动设置过标签的文档会跳过
+// @Tags         标签管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true   "知识库ID"
+// @Param        request  body      types.AutoTagRequest    false  "重新打标签的文档，为空时处理全部文档"
+// @Success      200      {object}  map[string]interface{}  "任务已提交"
+// @Failure      400      {object}  errors.AppError         "知识库没有标签或未配置模型"
+// @Failure      404      {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/auto-tag [post]
+func (h *AutoTagHandler) RetagKnowledgeBase(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.AutoTagRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.Error(ctx, "Failed to parse request parameters", err)
+			c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+			return
+		}
+	}
+	if err := h.autoTagService.RetagKnowledgeBase(ctx, kbID, req.KnowledgeIDs); err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"encoding/json"
+	goerrors "errors"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/application/service"
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// chatCompletionMaxRepairs caps the repair attempts a caller may request
+const chatCompletionMaxRepairs = 5
+
+// Response format types of a chat completion
+const (
+	ChatCompletionFormatText       = "text"
+	ChatCompletionFormatJSONObject = "json_object"
+	ChatCompletionFormatJSONSchema = "json_schema"
+)
+
+// jsonObjectSchema is the schema enforced for the json_object format
+var jsonObjectSchema = json.RawMessage(`{"type":"object"}`)
+
+// ChatCompletionMessage is one message of a chat completion request
+type ChatCompletionMessage struct {
+	Role    string `json:"role"    binding:"required,oneof=system user assistant"`
+	Content string `json:"content"`
+}
+
+// ChatCompletionJSONSchema is the schema of the json_schema response format
+type ChatCompletionJSONSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+// ChatCompletionResponseFormat selects plain text or JSON output. JSON
+// output is validated before it is returned; an invalid answer is sent back
+// to the model up to MaxRepairs times (default 2, at most 5).
+type ChatCompletionResponseFormat struct {
+	Type       string                    `json:"type"`
+	JSONSchema *ChatCompletionJSONSchema `json:"json_schema,omitempty"`
+	MaxRepairs *int                      `json:"max_repairs,omitempty"`
+}
+
+// ChatCompletionRequest is the body of a chat completion request
+type ChatCompletionRequest struct {
+	ModelID        string                        `json:"model_id"    binding:"required"`
+	Messages       []ChatCompletionMessage       `json:"messages"    binding:"required,min=1,dive"`
+	Temperature    *float64                      `json:"temperature"`
+	TopP           *float64                      `json:"top_p"`
+	MaxTokens      int                           `json:"max_tokens"`
+	ResponseFormat *ChatCompletionResponseFormat `json:"response_format,omitempty"`
+}
+
+// ChatCompletionResponse is the answer of a chat completion. Parsed holds
+// the validated JSON of a JSON response format; Attempts counts the model
+// calls it took.
+type ChatCompletionResponse struct {
+	ModelID      string           `json:"model_id"`
+	Content      string           `json:"content"`
+	Parsed       json.RawMessage  `json:"parsed,omitempty"`
+	FinishReason string           `json:"finish_reason,omitempty"`
+	Usage        types.TokenUsage `json:"usage"`
+	Attempts     int              `json:"attempts"`
+}
+
+// schema returns the JSON schema the answer must match, nil for plain text.
+func (f *ChatCompletionResponseFormat) schema() (json.RawMessage, error) {
+	if f == nil {
+		return nil, nil
+	}
+	switch f.Type {
+	case "", ChatCompletionFormatText:
+		return nil, nil
+	case ChatCompletionFormatJSONObject:
+		return jsonObjectSchema, nil
+	case ChatCompletionFormatJSONSchema:
+		if f.JSONSchema == nil || len(f.JSONSchema.Schema) == 0 {
+			return nil, goerrors.New("response_format.json_schema.schema is required")
+		}
+		if _, err := chat.CompileJSONSchema(f.JSONSchema.Schema); err != nil {
+			return nil, err
+		}
+		return f.JSONSchema.Schema, nil
+	default:
+		return nil, goerrors.New("response_format.type must be text, json_object or json_schema")
+	}
+}
+
+// maxRepairs returns the repair attempts of the format.
+func (f *ChatCompletionResponseFormat) maxRepairs() int {
+	if f == nil || f.MaxRepairs == nil {
+		return chat.DefaultStructuredOutputRepairs
+	}
+	return min(max(*f.MaxRepairs, 0), chatCompletionMaxRepairs)
+}
+
+// ChatCompletion godoc
+// @Summary      对话补全
+// @Description  直接调用对话模型生成回答（不检索知识库、不保存会话）。response_format 为 json_object 或 json_schema 时，
+// @Description  回答在返回前按 JSON Schema 校验：支持的供应商使用原生结构化输出，其余供应商在校验失败时把错误反馈给模型重试
+// @Tags         模型管理
+// @Accept       json
+// @Produce      json
+// @Param        request  body      ChatCompletionRequest   true  "对话请求"
+// @Success      200      {object}  ChatCompletionResponse  "模型回答"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      422      {object}  errors.AppError         "模型输出不符合 JSON Schema"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /chat/completions [post]
+func (h *ModelHandler) ChatCompletion(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req ChatCompletionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse chat completion request", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	schema, err := req.ResponseFormat.schema()
+	if err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	modelID := secutils.SanitizeForLog(req.ModelID)
+	model, err := h.service.GetModelByID(ctx, modelID)
+	if err != nil {
+		if err == service.ErrModelNotFound {
+			c.Error(errors.NewNotFoundError("Model not found"))
+			return
+		}
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	if model.Type != types.ModelTypeKnowledgeQA {
+		c.Error(errors.NewBadRequestError("model is not a chat model"))
+		return
+	}
+	instance, err := h.service.GetChatModel(ctx, modelID)
+	if err != nil {
+		logger.Errorf(ctx, "Failed to load chat model %s: %v", modelID, err)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	messages := make([]chat.Message, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		messages = append(messages, chat.Message{Role: msg.Role, Content: msg.Content})
+	}
+	opts := &chat.ChatOptions{MaxTokens: req.MaxTokens}
+	if req.Temperature != nil {
+		opts.Temperature = *req.Temperature
+	}
+	if req.TopP != nil {
+		opts.TopP = *req.TopP
+	}
+
+	result := ChatCompletionResponse{ModelID: model.ID, Attempts: 1}
+	if schema == nil {
+		resp, err := instance.Chat(ctx, messages, opts)
+		if err != nil {
+			h.chatCompletionError(c, err)
+			return
+		}
+		result.Content = resp.Content
+		result.FinishReason = resp.FinishReason
+		result.Usage = resp.Usage
+	} else {
+		structured, err := chat.ChatStructured(ctx, instance, messages, opts, schema, req.ResponseFormat.maxRepairs())
+		if err != nil {
+			h.chatCompletionError(c, err)
+			return
+		}
+		result.Content = structured.Response.Content
+		result.Parsed = structured.Value
+		result.FinishReason = structured.Response.FinishReason
+		result.Usage = structured.Response.Usage
+		result.Attempts = structured.Attempts
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// chatCompletionError reports a failed model call.
+func (h *ModelHandler) chatCompletionError(c *gin.Context, err error) {
+	ctx := c.Request.Context()
+	var outputErr *chat.StructuredOutputError
+	switch {
+	case goerrors.As(err, &outputErr):
+		logger.Warnf(ctx, "Chat completion output does not match the schema: %v", err)
+		c.Error(errors.NewStructuredOutputInvalidError(outputErr.Attempts, outputErr.Problem, outputErr.Content))
+	case goerrors.Is(err, types.ErrTokenBudgetExceeded):
+		c.Error(errors.NewTenantTokenBudgetExceededError())
+	default:
+		logger.Errorf(ctx, "Chat completion failed: %v", err)
+		c.Error(errors.NewInternalServerError(err.Error()))
+	}
+}
@@ -2,6 +2,8 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 
@@ -608,3 +610,40 @@ func (h *DataSourceHandler) GetAvailableConnectors(c *gin.Context) {
 	connectors := datasource.ListAvailableConnectors()
 	c.JSON(http.StatusOK, connectors)
 }
+
+// maxWebhookBodySize caps the webhook request body read for verification.
+const maxWebhookBodySize = 5 << 20
+
+// HandleWebhook godoc
+// @Summary Data source webhook
+// @Description Receive a change notification (e.g. a git push webhook) for a data source and trigger a sync.
+// @Description Public endpoint: the delivery is authenticated by the connector (webhook secret).
+// @Tags DataSource
+// @Accept json
+// @Produce json
+// @Param id path string true "Data source ID"
+// @Success 202 {object} map[string]bool
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /webhooks/datasource/{id} [post]
+func (h *DataSourceHandler) HandleWebhook(c *gin.Context) {
+	ctx := c.Request.Context()
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBodySize))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return
+	}
+
+	triggered, err := h.service.HandleWebhook(ctx, c.Param("id"), c.Request.Header, body)
+	switch {
+	case errors.Is(err, datasource.ErrDataSourceNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "data source not found"})
+	case errors.Is(err, datasource.ErrWebhookUnauthorized):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid webhook signature"})
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusAccepted, gin.H{"triggered": triggered})
+	}
+}
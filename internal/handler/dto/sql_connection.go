@@ -0,0 +1,75 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// SQLConnectionResponse mirrors types.SQLConnection for response bodies,
+// with the Password field removed by construction. Password presence is
+// exposed via Credentials.
+type SQLConnectionResponse struct {
+	ID            string                             `json:"id"`
+	TenantID      uint64                             `json:"tenant_id"`
+	Name          string                             `json:"name"`
+	Description   string                             `json:"description"`
+	Driver        types.SQLDriver                    `json:"driver"`
+	Parameters    SQLConnectionParametersDTO         `json:"parameters"`
+	AllowedTables []string                           `json:"allowed_tables"`
+	MaxRows       int                                `json:"max_rows"`
+	QueryTimeout  int                                `json:"query_timeout"`
+	CreatedAt     time.Time                          `json:"created_at"`
+	UpdatedAt     time.Time                          `json:"updated_at"`
+	Credentials   map[string]CredentialFieldMetadata `json:"credentials,omitempty"`
+}
+
+// SQLConnectionParametersDTO holds every parameter field except Password.
+type SQLConnectionParametersDTO struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port,omitempty"`
+	Database string `json:"database"`
+	Username string `json:"username"`
+	SSLMode  string `json:"ssl_mode,omitempty"`
+}
+
+// NewSQLConnectionResponse converts a stored connection into its response shape.
+func NewSQLConnectionResponse(c *types.SQLConnection) *SQLConnectionResponse {
+	if c == nil {
+		return nil
+	}
+	allowed := []string(c.AllowedTables)
+	if allowed == nil {
+		allowed = []string{}
+	}
+	return &SQLConnectionResponse{
+		ID:          c.ID,
+		TenantID:    c.TenantID,
+		Name:        c.Name,
+		Description: c.Description,
+		Driver:      c.Driver,
+		Parameters: SQLConnectionParametersDTO{
+			Host:     c.Parameters.Host,
+			Port:     c.Parameters.Port,
+			Database: c.Parameters.Database,
+			Username: c.Parameters.Username,
+			SSLMode:  c.Parameters.SSLMode,
+		},
+		AllowedTables: allowed,
+		MaxRows:       c.MaxRows,
+		QueryTimeout:  c.QueryTimeout,
+		CreatedAt:     c.CreatedAt,
+		UpdatedAt:     c.UpdatedAt,
+		Credentials: map[string]CredentialFieldMetadata{
+			"password": {Configured: c.Parameters.Password != ""},
+		},
+	}
+}
+
+func NewSQLConnectionResponses(cs []*types.SQLConnection) []*SQLConnectionResponse {
+	out := make([]*SQLConnectionResponse, 0, len(cs))
+	for _, c := range cs {
+		out = append(out, NewSQLConnectionResponse(c))
+	}
+	return out
+}
@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLConnectionResponse_OmitsPassword(t *testing.T) {
+	c := &types.SQLConnection{
+		ID:     "sql-1",
+		Name:   "sales",
+		Driver: types.SQLDriverPostgres,
+		Parameters: types.SQLConnectionParameters{
+			Host:     "db.example.com",
+			Database: "sales",
+			Username: "reader",
+			Password: "pg-secret-do-not-leak",
+		},
+	}
+	body, err := json.Marshal(NewSQLConnectionResponse(c))
+	assert.NoError(t, err)
+	s := string(body)
+	assert.NotContains(t, s, "pg-secret-do-not-leak")
+	assert.Contains(t, s, `"password":{"configured":true}`)
+	assert.Contains(t, s, "db.example.com")
+	assert.Contains(t, s, `"allowed_tables":[]`)
+}
+
+func TestSQLConnectionResponse_NilSafe(t *testing.T) {
+	assert.Nil(t, NewSQLConnectionResponse(nil))
+	assert.Equal(t, []*SQLConnectionResponse{}, NewSQLConnectionResponses(nil))
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// DuplicateHandler exposes duplicate content detection of a knowledge base.
+// KB access is checked by the route-level KBAccessRead/KBAccessWrite guards.
+type DuplicateHandler struct {
+	duplicateService interfaces.DuplicateService
+}
+
+// NewDuplicateHandler creates a new DuplicateHandler.
+func NewDuplicateHandler(duplicateService interfaces.DuplicateService) *DuplicateHandler {
+	return &DuplicateHandler{duplicateService: duplicateService}
+}
+
+// GetDuplicateReport godoc
+// @Summary      获取重复内容报告
+// @Description  获取知识库最近一次重复内容分析的报告，包含重复文档组与重复分块组
+// @Tags         重复内容检测
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "分析报告"
+// @Failure      404  {object}  errors.AppError         "知识库不存在或尚未分析"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/duplicates [get]
+func (h *DuplicateHandler) GetDuplicateReport(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	report, err := h.duplicateService.GetDuplicateReport(ctx, kbID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// AnalyzeDuplicates godoc
+// @Summary      分析重复内容
+// @Description  异步查找知识库中完全重复与相似度超过阈值的文档和分块（MinHash），完成后替换现有报告
+// @Tags         重复内容检测
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                          true   "知识库ID"
+// @Param        request  body      types.DuplicateAnalysisRequest  false  "分析参数"
+// @Success      200      {object}  map[string]interface{}          "进行中的报告"
+// @Failure      400      {object}  errors.AppError                 "参数错误或分析正在进行"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/duplicates/analyze [post]
+func (h *DuplicateHandler) AnalyzeDuplicates(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.DuplicateAnalysisRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.Error(ctx, "Failed to parse request parameters", err)
+			c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+			return
+		}
+	}
+	report, err := h.duplicateService.AnalyzeDuplicates(ctx, kbID, &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// RemoveDuplicates godoc
+// @Summary      一键去重
+// @Description  按最近一次报告禁用（默认）或删除冗余的文档与分块，保留每组中最早的副本；自分析后被修改或删除的内容会跳过
+// @Tags         重复内容检测
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                        true   "知识库ID"
+// @Param        request  body      types.DuplicateRemoveRequest  false  "去重参数"
+// @Success      200      {object}  map[string]interface{}        "去重结果"
+// @Failure      400      {object}  errors.AppError               "没有已完成的报告"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/duplicates/remove [post]
+func (h *DuplicateHandler) RemoveDuplicates(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.DuplicateRemoveRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.Error(ctx, "Failed to parse request parameters", err)
+			c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+			return
+		}
+	}
+	result, err := h.duplicateService.RemoveDuplicates(ctx, kbID, &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
@@ -107,6 +107,9 @@ func (f *flowTenantSvc) ListTenants(context.Context) ([]*types.Tenant, error) {
 func (f *flowTenantSvc) UpdateAPIKey(context.Context, uint64) (string, error) {
 	return "", nil
 }
+func (f *flowTenantSvc) UpdateAPIKeyRole(context.Context, uint64, types.TenantRole) (*types.Tenant, error) {
+	return nil, nil
+}
 func (f *flowTenantSvc) ExtractTenantIDFromAPIKey(string) (uint64, error) { return 0, nil }
 func (f *flowTenantSvc) ListAllTenants(context.Context) ([]*types.Tenant, error) {
 	return nil, nil
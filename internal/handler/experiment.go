@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// ExperimentHandler manages shadow experiments, which answer a sample of
+// production questions again with an alternative pipeline configuration
+// and report how often the judge prefers it.
+type ExperimentHandler struct {
+	experimentService interfaces.ExperimentService
+}
+
+// NewExperimentHandler creates a new ExperimentHandler.
+func NewExperimentHandler(experimentService interfaces.ExperimentService) *ExperimentHandler {
+	return &ExperimentHandler{experimentService: experimentService}
+}
+
+// CreateExperiment godoc
+// @Summary      创建影子实验
+// @Description  按采样比例用另一套检索与模型配置在后台重新回答线上问题，由评判模型与线上回答对比；不影响返回给用户的回答
+// @Tags         影子实验
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.ExperimentRequest  true  "实验配置"
+// @Success      200      {object}  map[string]interface{}   "实验"
+// @Failure      400      {object}  errors.AppError          "请求参数错误"
+// @Security     Bearer
+// @Router       /experiments [post]
+func (h *ExperimentHandler) CreateExperiment(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.ExperimentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to bind experiment payload", err)
+		c.Error(apperrors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	experiment, err := h.experimentService.CreateExperiment(ctx, &req)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to create experiment: %v", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    experiment,
+	})
+}
+
+// ListExperiments godoc
+// @Summary      获取影子实验列表
+// @Description  获取当前租户的影子实验及其胜率报告，按创建时间倒序
+// @Tags         影子实验
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "实验列表"
+// @Security     Bearer
+// @Router       /experiments [get]
+func (h *ExperimentHandler) ListExperiments(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	experiments, err := h.experimentService.ListExperiments(ctx)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to list experiments: %v", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    experiments,
+	})
+}
+
+// GetExperiment godoc
+// @Summary      获取影子实验
+// @Description  获取影子实验及其胜率报告
+// @Tags         影子实验
+// @Produce      json
+// @Param        id   path      string                  true  "实验 ID"
+// @Success      200  {object}  map[string]interface{}  "实验"
+// @Failure      404  {object}  errors.AppError         "实验不存在"
+// @Security     Bearer
+// @Router       /experiments/{id} [get]
+func (h *ExperimentHandler) GetExperiment(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+
+	experiment, err := h.experimentService.GetExperiment(ctx, id)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get experiment %s: %v", id, err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    experiment,
+	})
+}
+
+// UpdateExperiment godoc
+// @Summary      更新影子实验
+// @Description  修改实验名称、采样比例或样本上限，暂停或恢复实验；实验配置不可修改
+// @Tags         影子实验
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                         true  "实验 ID"
+// @Param        request  body      types.ExperimentUpdateRequest  true  "要修改的字段"
+// @Success      200      {object}  map[string]interface{}         "实验"
+// @Failure      400      {object}  errors.AppError                "请求参数错误"
+// @Failure      404      {object}  errors.AppError                "实验不存在"
+// @Security     Bearer
+// @Router       /experiments/{id} [put]
+func (h *ExperimentHandler) UpdateExperiment(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.ExperimentUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to bind experiment update payload", err)
+		c.Error(apperrors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	experiment, err := h.experimentService.UpdateExperiment(ctx, id, &req)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to update experiment %s: %v", id, err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    experiment,
+	})
+}
+
+// DeleteExperiment godoc
+// @Summary      删除影子实验
+// @Description  删除影子实验及其全部样本
+// @Tags         影子实验
+// @Produce      json
+// @Param        id   path      string                  true  "实验 ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      404  {object}  errors.AppError         "实验不存在"
+// @Security     Bearer
+// @Router       /experiments/{id} [delete]
+func (h *ExperimentHandler) DeleteExperiment(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+
+	if err := h.experimentService.DeleteExperiment(ctx, id); err != nil {
+		logger.Warnf(ctx, "Failed to delete experiment %s: %v", id, err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// ListSamples godoc
+// @Summary      获取影子实验样本
+// @Description  分页获取实验的样本：线上回答、影子回答、各自的来源与评判结果，按时间倒序
+// @Tags         影子实验
+// @Produce      json
+// @Param        id         path      string                  true   "实验 ID"
+// @Param        winner     query     string                  false  "只列出该评判结果：production、shadow、tie、failed"
+// @Param        page       query     int                     false  "页码"
+// @Param        page_size  query     int                     false  "每页数量"
+// @Success      200        {object}  map[string]interface{}  "样本分页"
+// @Failure      400        {object}  errors.AppError         "请求参数错误"
+// @Failure      404        {object}  errors.AppError         "实验不存在"
+// @Security     Bearer
+// @Router       /experiments/{id}/samples [get]
+func (h *ExperimentHandler) ListSamples(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+
+	var page types.Pagination
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.Error(apperrors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	result, err := h.experimentService.ListSamples(ctx, id, secutils.SanitizeForLog(c.Query("winner")), &page)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to list samples of experiment %s: %v", id, err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
@@ -0,0 +1,201 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// FeedbackHandler records users' feedback on answers and serves the
+// aggregates and exports used to tune retrieval.
+type FeedbackHandler struct {
+	feedbackService interfaces.FeedbackService
+}
+
+// NewFeedbackHandler creates a new FeedbackHandler.
+func NewFeedbackHandler(feedbackService interfaces.FeedbackService) *FeedbackHandler {
+	return &FeedbackHandler{feedbackService: feedbackService}
+}
+
+// SubmitFeedback godoc
+// @Summary      提交回答反馈
+// @Description  对回答点赞或点踩，并可标记错误的引用来源；同一用户对同一条回答只保留最后一次反馈
+// @Tags         回答反馈
+// @Accept       json
+// @Produce      json
+// @Param        session_id  path      string                        true  "会话ID"
+// @Param        id          path      string                        true  "消息ID"
+// @Param        request     body      types.MessageFeedbackRequest  true  "反馈"
+// @Success      200         {object}  map[string]interface{}        "反馈"
+// @Failure      400         {object}  errors.AppError               "请求参数错误"
+// @Failure      404         {object}  errors.AppError               "会话或消息不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /messages/{session_id}/{id}/feedback [put]
+func (h *FeedbackHandler) SubmitFeedback(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	messageID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.MessageFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to bind feedback payload", err)
+		c.Error(apperrors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	feedback, err := h.feedbackService.SubmitFeedback(ctx, sessionID, messageID, &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+			"message_id": messageID,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    feedback,
+	})
+}
+
+// GetFeedback godoc
+// @Summary      获取回答反馈
+// @Description  获取当前用户对回答的反馈，没有反馈时 data 为 null
+// @Tags         回答反馈
+// @Produce      json
+// @Param        session_id  path      string                  true  "会话ID"
+// @Param        id          path      string                  true  "消息ID"
+// @Success      200         {object}  map[string]interface{}  "反馈"
+// @Failure      404         {object}  errors.AppError         "会话或消息不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /messages/{session_id}/{id}/feedback [get]
+func (h *FeedbackHandler) GetFeedback(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	messageID := secutils.SanitizeForLog(c.Param("id"))
+
+	feedback, err := h.feedbackService.GetFeedback(ctx, sessionID, messageID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+			"message_id": messageID,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    feedback,
+	})
+}
+
+// DeleteFeedback godoc
+// @Summary      撤销回答反馈
+// @Description  删除当前用户对回答的反馈
+// @Tags         回答反馈
+// @Produce      json
+// @Param        session_id  path      string                  true  "会话ID"
+// @Param        id          path      string                  true  "消息ID"
+// @Success      200         {object}  map[string]interface{}  "删除成功"
+// @Failure      404         {object}  errors.AppError         "会话或消息不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /messages/{session_id}/{id}/feedback [delete]
+func (h *FeedbackHandler) DeleteFeedback(c *gin.Context) {
+	ctx := c.Request.Context()
+	sessionID := secutils.SanitizeForLog(c.Param("session_id"))
+	messageID := secutils.SanitizeForLog(c.Param("id"))
+
+	if err := h.feedbackService.DeleteFeedback(ctx, sessionID, messageID); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"session_id": sessionID,
+			"message_id": messageID,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// feedbackQuery reads the date range and filters of the stats and export endpoints
+func feedbackQuery(c *gin.Context) *types.FeedbackQuery {
+	return &types.FeedbackQuery{
+		StartDay:        secutils.SanitizeForLog(c.Query("start_date")),
+		EndDay:          secutils.SanitizeForLog(c.Query("end_date")),
+		KnowledgeBaseID: secutils.SanitizeForLog(c.Query("knowledge_base_id")),
+		Rating:          types.FeedbackRating(secutils.SanitizeForLog(c.Query("rating"))),
+	}
+}
+
+// GetStats godoc
+// @Summary      获取反馈统计
+// @Description  按天、知识库和来源分数区间汇总当前租户的回答反馈，并列出最常被标记为错误的来源；日期为 UTC，默认最近 30 天
+// @Tags         回答反馈
+// @Produce      json
+// @Param        start_date         query     string               false  "开始日期（YYYY-MM-DD）"
+// @Param        end_date           query     string               false  "结束日期（YYYY-MM-DD，含当天）"
+// @Param        knowledge_base_id  query     string               false  "只统计该知识库的来源"
+// @Param        rating             query     string               false  "只统计该评价：up、down"
+// @Success      200                {object}  types.FeedbackStats  "反馈统计"
+// @Failure      400                {object}  errors.AppError      "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /feedback/stats [get]
+func (h *FeedbackHandler) GetStats(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	stats, err := h.feedbackService.GetStats(ctx, feedbackQuery(c))
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get feedback stats: %v", err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    stats,
+	})
+}
+
+// Export godoc
+// @Summary      导出反馈
+// @Description  以 JSON Lines 导出当前租户的回答反馈：feedback 为原始反馈，eval 为可直接上传的评测数据集条目，rerank 为重排模型训练样本
+// @Tags         回答反馈
+// @Produce      application/x-ndjson
+// @Param        format             query     string  false  "导出格式：feedback（默认）、eval、rerank"
+// @Param        start_date         query     string  false  "开始日期（YYYY-MM-DD）"
+// @Param        end_date           query     string  false  "结束日期（YYYY-MM-DD，含当天）"
+// @Param        knowledge_base_id  query     string  false  "只导出引用了该知识库的反馈"
+// @Param        rating             query     string  false  "只导出该评价：up、down"
+// @Success      200                {file}    file    "JSONL 文件"
+// @Failure      400                {object}  errors.AppError  "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /feedback/export [get]
+func (h *FeedbackHandler) Export(c *gin.Context) {
+	ctx := c.Request.Context()
+	format := types.FeedbackExportFormat(secutils.SanitizeForLog(c.DefaultQuery("format", string(types.FeedbackExportRaw))))
+
+	data, err := h.feedbackService.Export(ctx, feedbackQuery(c), format)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to export feedback: %v", err)
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=feedback_"+string(format)+".jsonl")
+	c.Data(http.StatusOK, "application/x-ndjson; charset=utf-8", data)
+}
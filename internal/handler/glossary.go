@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// GlossaryHandler exposes the automatically built knowledge base glossary.
+// KB access is checked by the route-level KBAccessRead/KBAccessWrite guards.
+type GlossaryHandler struct {
+	glossaryService interfaces.GlossaryService
+}
+
+// NewGlossaryHandler creates a new GlossaryHandler.
+func NewGlossaryHandler(glossaryService interfaces.GlossaryService) *GlossaryHandler {
+	return &GlossaryHandler{glossaryService: glossaryService}
+}
+
+// ListGlossary godoc
+// @Summary      获取术语表
+// @Description  获取知识库自动构建的术语表
+// @Tags         术语表
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "术语列表"
+// @Failure      404  {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/glossary [get]
+func (h *GlossaryHandler) ListGlossary(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	entries, err := h.glossaryService.ListGlossary(ctx, kbID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+	if entries == nil {
+		entries = []*types.GlossaryEntry{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entries,
+	})
+}
+
+// BuildGlossary godoc
+// @Summary      构建术语表
+// @Description  异步从知识库文档中挖掘术语并由摘要模型生成定义，完成后替换现有术语表
+// @Tags         术语表
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "知识库ID"
+// @Success      200  {object}  map[string]interface{}  "任务已提交"
+// @Failure      400  {object}  errors.AppError         "知识库未配置摘要模型"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/glossary/build [post]
+func (h *GlossaryHandler) BuildGlossary(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	if err := h.glossaryService.BuildGlossary(ctx, kbID); err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
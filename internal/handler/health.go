@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// HealthHandler reports the health of the services the server depends on
+type HealthHandler struct {
+	healthService interfaces.HealthService
+}
+
+// NewHealthHandler creates a new HealthHandler.
+func NewHealthHandler(healthService interfaces.HealthService) *HealthHandler {
+	return &HealthHandler{healthService: healthService}
+}
+
+// GetSystemHealth godoc
+// @Summary      获取系统健康状态
+// @Description  并行探测数据库、Redis、Milvus（含各集合的加载状态）、Neo4j、对象存储以及当前租户各模型的服务地址，返回各依赖的状态与耗时。数据库或 Redis 不可用时整体为 down 并返回 503，其他依赖异常时整体为 degraded
+// @Tags         系统
+// @Produce      json
+// @Success      200  {object}  types.SystemHealth  "系统健康状态"
+// @Failure      503  {object}  types.SystemHealth  "关键依赖不可用"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /system/health [get]
+func (h *HealthHandler) GetSystemHealth(c *gin.Context) {
+	health := h.healthService.CheckHealth(c.Request.Context())
+
+	status := http.StatusOK
+	if health.Status == types.HealthStatusDown {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, gin.H{
+		"success": health.Status != types.HealthStatusDown,
+		"data":    health,
+	})
+}
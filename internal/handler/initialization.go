@@ -113,6 +113,11 @@ type KBModelConfigRequest struct {
 		Strategy   *string   `json:"strategy,omitempty"`
 		TokenLimit *int      `json:"tokenLimit,omitempty"`
 		Languages  *[]string `json:"languages,omitempty"`
+		// TableChunking / TableSummary / ContextualRetrieval follow the same
+		// rule: nil keeps the stored value.
+		TableChunking       *bool `json:"tableChunking,omitempty"`
+		TableSummary        *bool `json:"tableSummary,omitempty"`
+		ContextualRetrieval *bool `json:"contextualRetrieval,omitempty"`
 	} `json:"documentSplitting"`
 
 	// 多模态配置（仅模型相关；存储引擎在 storageProvider 中配置）
@@ -134,8 +139,9 @@ type KBModelConfigRequest struct {
 
 	// 问题生成配置
 	QuestionGeneration struct {
-		Enabled       bool `json:"enabled"`
-		QuestionCount int  `json:"questionCount"`
+		Enabled       bool   `json:"enabled"`
+		QuestionCount int    `json:"questionCount"`
+		ModelID       string `json:"modelId"`
 	} `json:"questionGeneration"`
 }
 
@@ -208,8 +214,9 @@ type InitializationRequest struct {
 	} `json:"nodeExtract"`
 
 	QuestionGeneration struct {
-		Enabled       bool `json:"enabled"`
-		QuestionCount int  `json:"questionCount"`
+		Enabled       bool   `json:"enabled"`
+		QuestionCount int    `json:"questionCount"`
+		ModelID       string `json:"modelId"`
 	} `json:"questionGeneration"`
 }
 
@@ -310,6 +317,7 @@ func (h *InitializationHandler) UpdateKBConfig(c *gin.Context) {
 			kb.ASRConfig.Enabled = true
 			kb.ASRConfig.ModelID = req.ASRConfig.ModelID
 			kb.ASRConfig.Language = req.ASRConfig.Language
+			kb.ASRConfig.ChunkSeconds = max(req.ASRConfig.ChunkSeconds, 0)
 		}
 	}
 
@@ -343,6 +351,15 @@ func (h *InitializationHandler) UpdateKBConfig(c *gin.Context) {
 	if req.DocumentSplitting.Languages != nil {
 		kb.ChunkingConfig.Languages = *req.DocumentSplitting.Languages
 	}
+	if req.DocumentSplitting.TableChunking != nil {
+		kb.ChunkingConfig.TableChunking = *req.DocumentSplitting.TableChunking
+	}
+	if req.DocumentSplitting.TableSummary != nil {
+		kb.ChunkingConfig.TableSummary = *req.DocumentSplitting.TableSummary
+	}
+	if req.DocumentSplitting.ContextualRetrieval != nil {
+		kb.ChunkingConfig.ContextualRetrieval = *req.DocumentSplitting.ContextualRetrieval
+	}
 
 	// 更新多模态配置
 	if req.Multimodal.Enabled {
@@ -413,6 +430,7 @@ func (h *InitializationHandler) UpdateKBConfig(c *gin.Context) {
 		kb.QuestionGenerationConfig = &types.QuestionGenerationConfig{
 			Enabled:       true,
 			QuestionCount: questionCount,
+			ModelID:       req.QuestionGeneration.ModelID,
 		}
 	} else {
 		kb.QuestionGenerationConfig = &types.QuestionGenerationConfig{Enabled: false}
@@ -610,9 +628,9 @@ func validateNodeExtractConfig(ctx context.Context, req *InitializationRequest)
 	if !req.NodeExtract.Enabled {
 		return nil
 	}
-	if strings.ToLower(os.Getenv("NEO4J_ENABLE")) != "true" {
+	if !types.GraphStoreEnabled() {
 		logger.Error(ctx, "Node Extractor configuration incomplete")
-		return errors.NewBadRequestError("请正确配置环境变量NEO4J_ENABLE")
+		return errors.NewBadRequestError("请正确配置环境变量NEO4J_ENABLE或GRAPH_DRIVER")
 	}
 	if req.NodeExtract.Text == "" || len(req.NodeExtract.Tags) == 0 {
 		logger.Error(ctx, "Node Extractor configuration incomplete")
@@ -1470,6 +1488,13 @@ func (h *InitializationHandler) buildConfigResponse(ctx context.Context, models
 		if len(kb.ChunkingConfig.Languages) > 0 {
 			ds["languages"] = kb.ChunkingConfig.Languages
 		}
+		if kb.ChunkingConfig.TableChunking {
+			ds["tableChunking"] = true
+			ds["tableSummary"] = kb.ChunkingConfig.TableSummary
+		}
+		if kb.ChunkingConfig.ContextualRetrieval {
+			ds["contextualRetrieval"] = true
+		}
 		config["documentSplitting"] = ds
 
 		// 添加多模态的存储配置信息（优先读新字段，兼容旧 cos_config）
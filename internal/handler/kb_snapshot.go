@@ -0,0 +1,247 @@
+package handler
+
+import (
+	stderrors "errors"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/gin-gonic/gin"
+)
+
+// snapshotError maps knowledge base snapshot errors to API errors.
+func snapshotError(c *gin.Context, err error) {
+	if stderrors.Is(err, repository.ErrKnowledgeBaseNotFound) {
+		c.Error(apperrors.NewNotFoundError("Knowledge base not found"))
+		return
+	}
+	if appErr, ok := apperrors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	logger.ErrorWithFields(c.Request.Context(), err, nil)
+	c.Error(apperrors.NewInternalServerError(err.Error()))
+}
+
+// CreateKBSnapshot godoc
+// @Summary      创建知识库快照
+// @Description  异步将知识库配置、标签、文档、分块及（引擎支持时）向量写入对象存储
+// @Tags         知识库快照
+// @Produce      json
+// @Param        id   path      string                  true  "知识库 ID"
+// @Success      200  {object}  map[string]interface{}  "快照记录"
+// @Failure      400  {object}  errors.AppError         "知识库类型不支持快照"
+// @Failure      404  {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/snapshots [post]
+func (h *KnowledgeBaseHandler) CreateKBSnapshot(c *gin.Context) {
+	snapshot, err := h.knowledgeService.CreateKBSnapshot(c.Request.Context(), c.Param("id"), types.KBSnapshotTriggerManual)
+	if err != nil {
+		snapshotError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    snapshot,
+	})
+}
+
+// ListKBSnapshots godoc
+// @Summary      获取知识库快照列表
+// @Description  获取知识库的快照，按创建时间倒序
+// @Tags         知识库快照
+// @Produce      json
+// @Param        id   path      string                  true  "知识库 ID"
+// @Success      200  {object}  map[string]interface{}  "快照列表"
+// @Failure      404  {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/snapshots [get]
+func (h *KnowledgeBaseHandler) ListKBSnapshots(c *gin.Context) {
+	snapshots, err := h.knowledgeService.ListKBSnapshots(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		snapshotError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    snapshots,
+	})
+}
+
+// GetKBSnapshotPolicy godoc
+// @Summary      获取知识库快照计划
+// @Description  获取知识库的定时快照配置；未配置时返回默认的关闭状态
+// @Tags         知识库快照
+// @Produce      json
+// @Param        id   path      string                  true  "知识库 ID"
+// @Success      200  {object}  map[string]interface{}  "快照计划"
+// @Failure      404  {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/snapshot-policy [get]
+func (h *KnowledgeBaseHandler) GetKBSnapshotPolicy(c *gin.Context) {
+	policy, err := h.knowledgeService.GetKBSnapshotPolicy(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		snapshotError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    policy,
+	})
+}
+
+// UpdateKBSnapshotPolicy godoc
+// @Summary      更新知识库快照计划
+// @Description  设置定时快照的开关、间隔（小时）与保留份数
+// @Tags         知识库快照
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "知识库 ID"
+// @Param        request  body      types.KBSnapshotPolicy  true  "快照计划"
+// @Success      200      {object}  map[string]interface{}  "快照计划"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/snapshot-policy [put]
+func (h *KnowledgeBaseHandler) UpdateKBSnapshotPolicy(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req types.KBSnapshotPolicy
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+	policy, err := h.knowledgeService.UpdateKBSnapshotPolicy(ctx, c.Param("id"), &req)
+	if err != nil {
+		snapshotError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    policy,
+	})
+}
+
+// ListTenantKBSnapshots godoc
+// @Summary      获取租户知识库快照列表
+// @Description  获取当前租户所有知识库（含已删除的知识库）的快照，可按 knowledge_base_id 过滤
+// @Tags         知识库快照
+// @Produce      json
+// @Param        knowledge_base_id  query     string                  false  "知识库 ID"
+// @Success      200                {object}  map[string]interface{}  "快照列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /kb-snapshots [get]
+func (h *KnowledgeBaseHandler) ListTenantKBSnapshots(c *gin.Context) {
+	ctx := c.Request.Context()
+	snapshots, err := h.knowledgeService.ListKBSnapshots(ctx, "")
+	if err != nil {
+		snapshotError(c, err)
+		return
+	}
+	if kbID := c.Query("knowledge_base_id"); kbID != "" {
+		filtered := make([]*types.KBSnapshot, 0, len(snapshots))
+		for _, snapshot := range snapshots {
+			if snapshot.KnowledgeBaseID == kbID {
+				filtered = append(filtered, snapshot)
+			}
+		}
+		snapshots = filtered
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    snapshots,
+	})
+}
+
+// GetKBSnapshot godoc
+// @Summary      获取知识库快照
+// @Tags         知识库快照
+// @Produce      json
+// @Param        id   path      string                  true  "快照 ID"
+// @Success      200  {object}  map[string]interface{}  "快照记录"
+// @Failure      404  {object}  errors.AppError         "快照不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /kb-snapshots/{id} [get]
+func (h *KnowledgeBaseHandler) GetKBSnapshot(c *gin.Context) {
+	snapshot, err := h.knowledgeService.GetKBSnapshot(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		snapshotError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    snapshot,
+	})
+}
+
+// DeleteKBSnapshot godoc
+// @Summary      删除知识库快照
+// @Description  删除快照记录及其在对象存储中的归档文件
+// @Tags         知识库快照
+// @Produce      json
+// @Param        id   path      string                  true  "快照 ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      400  {object}  errors.AppError         "快照正在生成"
+// @Failure      404  {object}  errors.AppError         "快照不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /kb-snapshots/{id} [delete]
+func (h *KnowledgeBaseHandler) DeleteKBSnapshot(c *gin.Context) {
+	if err := h.knowledgeService.DeleteKBSnapshot(c.Request.Context(), c.Param("id")); err != nil {
+		snapshotError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// RestoreKBSnapshotRequest is the body of a snapshot restore request.
+type RestoreKBSnapshotRequest struct {
+	// Name of the restored knowledge base; defaults to the snapshot's
+	// knowledge base name with the snapshot time
+	Name string `json:"name"`
+}
+
+// RestoreKBSnapshot godoc
+// @Summary      从快照恢复知识库
+// @Description  以快照内容创建一个新的知识库，重写所有 ID；进度通过知识库复制进度接口查询
+// @Tags         知识库快照
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                    true   "快照 ID"
+// @Param        request  body      RestoreKBSnapshotRequest  false  "恢复参数"
+// @Success      200      {object}  map[string]interface{}    "恢复任务进度"
+// @Failure      400      {object}  errors.AppError           "快照未完成"
+// @Failure      404      {object}  errors.AppError           "快照不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /kb-snapshots/{id}/restore [post]
+func (h *KnowledgeBaseHandler) RestoreKBSnapshot(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req RestoreKBSnapshotRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.Error(ctx, "Failed to parse request parameters", err)
+			c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+			return
+		}
+	}
+	progress, err := h.knowledgeService.RestoreKBSnapshot(ctx, c.Param("id"), req.Name)
+	if err != nil {
+		snapshotError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/agent/tools"
 	"github.com/Tencent/WeKnora/internal/application/repository"
 	"github.com/Tencent/WeKnora/internal/application/service"
+	filesvc "github.com/Tencent/WeKnora/internal/application/service/file"
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
@@ -25,6 +26,7 @@ import (
 	secutils "github.com/Tencent/WeKnora/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/hibiken/asynq"
+	"gorm.io/gorm"
 )
 
 // KnowledgeHandler processes HTTP requests related to knowledge resources
@@ -203,6 +205,21 @@ func (h *KnowledgeHandler) handleDuplicateKnowledgeError(c *gin.Context,
 	return false
 }
 
+// handleStorageQuotaError reports a storage quota error of the service as
+// a structured 429. Returns true if the error was handled.
+func (h *KnowledgeHandler) handleStorageQuotaError(c *gin.Context, err error) bool {
+	var quotaErr *types.StorageQuotaExceededError
+	if !goerrors.As(err, &quotaErr) {
+		return false
+	}
+	var used, quota int64
+	if tenant, ok := types.TenantInfoFromContext(c.Request.Context()); ok {
+		used, quota = tenant.StorageUsed, tenant.StorageQuota
+	}
+	c.Error(errors.NewTenantStorageQuotaExceededError(used, quota))
+	return true
+}
+
 // enqueueKnowledgeListDelete enqueues an async batch-delete task for the
 // given knowledge IDs and returns the asynq task ID.
 func (h *KnowledgeHandler) enqueueKnowledgeListDelete(
@@ -373,6 +390,9 @@ func (h *KnowledgeHandler) CreateKnowledgeFromFile(c *gin.Context) {
 		if h.handleDuplicateKnowledgeError(c, err, knowledge, "file") {
 			return
 		}
+		if h.handleStorageQuotaError(c, err) {
+			return
+		}
 		if appErr, ok := errors.IsAppError(err); ok {
 			c.Error(appErr)
 			return
@@ -394,6 +414,176 @@ func (h *KnowledgeHandler) CreateKnowledgeFromFile(c *gin.Context) {
 	})
 }
 
+// CreateKnowledgeFromCodeArchive godoc
+// @Summary      从代码压缩包创建知识
+// @Description  上传代码仓库的 zip 压缩包，每个源代码文件创建一条知识，按函数/类切分并记录文件路径与符号
+// @Tags         知识管理
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id       path      string  true   "知识库ID"
+// @Param        file     formData  file    true   "代码仓库 zip 压缩包"
+// @Param        tag_ids  formData  string  false  "分类ID列表，逗号分隔"
+// @Success      200      {object}  types.CodeArchiveImportResult  "导入结果"
+// @Failure      400      {object}  errors.AppError                "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/knowledge/code-archive [post]
+func (h *KnowledgeHandler) CreateKnowledgeFromCodeArchive(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, kbID, effectiveTenantID, permission, err := h.validateKnowledgeBaseAccess(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
+	if permission != types.OrgRoleAdmin && permission != types.OrgRoleEditor {
+		c.Error(errors.NewForbiddenError("No permission to create knowledge"))
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		logger.Error(ctx, "File upload failed", err)
+		c.Error(errors.NewBadRequestError("File upload failed").WithDetails(err.Error()))
+		return
+	}
+	maxSizeMB := utils.GetMaxFileSizeMB()
+	if file.Size > maxSizeMB*1024*1024 {
+		c.Error(errors.NewBadRequestError(fmt.Sprintf("文件大小不能超过%dMB", maxSizeMB)))
+		return
+	}
+	logger.Infof(ctx, "Importing code archive %s (%.2f KB) into knowledge base %s",
+		secutils.SanitizeForLog(file.Filename), float64(file.Size)/1024, kbID)
+
+	tagIDs := parseCommaSeparatedTagIDs(c.PostForm("tag_ids"))
+	result, err := h.kgService.CreateKnowledgeFromCodeArchive(ctx, kbID, file, tagIDs, c.PostForm("channel"))
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// ImportChunks godoc
+// @Summary      导入预分块数据
+// @Description  上传 JSONL 文件批量导入已分块（可附带向量）的文档，跳过文档解析。每行一个分块，同一 document_id 的行必须连续；
+// @Description  附带的 embedding 维度须与知识库向量模型一致。异步执行，返回 task_id，通过进度接口查询
+// @Tags         知识管理
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id          path      string  true   "知识库ID"
+// @Param        file        formData  file    true   "JSONL 文件"
+// @Param        batch_size  formData  int     false  "每批写入的分块数，默认 100，最大 1000"
+// @Param        rate_limit  formData  int     false  "每秒最多写入的分块数，0 表示不限速"
+// @Success      200         {object}  types.ChunkImportProgress  "导入任务"
+// @Failure      400         {object}  errors.AppError            "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/knowledge/chunks-import [post]
+func (h *KnowledgeHandler) ImportChunks(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, kbID, effectiveTenantID, permission, err := h.validateKnowledgeBaseAccess(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, effectiveTenantID)
+	if permission != types.OrgRoleAdmin && permission != types.OrgRoleEditor {
+		c.Error(errors.NewForbiddenError("No permission to create knowledge"))
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		logger.Error(ctx, "File upload failed", err)
+		c.Error(errors.NewBadRequestError("File upload failed").WithDetails(err.Error()))
+		return
+	}
+	maxSizeMB := utils.GetMaxFileSizeMB()
+	if file.Size > maxSizeMB*1024*1024 {
+		c.Error(errors.NewBadRequestError(fmt.Sprintf("文件大小不能超过%dMB", maxSizeMB)))
+		return
+	}
+	batchSize, rateLimit := 0, 0
+	if v := c.PostForm("batch_size"); v != "" {
+		if batchSize, err = strconv.Atoi(v); err != nil || batchSize < 0 {
+			c.Error(errors.NewBadRequestError("batch_size 必须为非负整数"))
+			return
+		}
+	}
+	if v := c.PostForm("rate_limit"); v != "" {
+		if rateLimit, err = strconv.Atoi(v); err != nil || rateLimit < 0 {
+			c.Error(errors.NewBadRequestError("rate_limit 必须为非负整数"))
+			return
+		}
+	}
+	logger.Infof(ctx, "Importing chunks from %s (%.2f KB) into knowledge base %s",
+		secutils.SanitizeForLog(file.Filename), float64(file.Size)/1024, kbID)
+
+	progress, err := h.kgService.StartChunkImport(ctx, kbID, file, batchSize, rateLimit, c.PostForm("channel"))
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
+
+// GetChunkImportProgress godoc
+// @Summary      获取预分块数据导入进度
+// @Description  获取分块导入任务的进度、已写入的文档/分块数与出错行
+// @Tags         知识管理
+// @Produce      json
+// @Param        id       path      string  true  "知识库ID"
+// @Param        task_id  path      string  true  "任务ID"
+// @Success      200      {object}  types.ChunkImportProgress  "导入进度"
+// @Failure      404      {object}  errors.AppError            "任务不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/knowledge/chunks-import/{task_id} [get]
+func (h *KnowledgeHandler) GetChunkImportProgress(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	_, kbID, _, _, err := h.validateKnowledgeBaseAccess(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	taskID := secutils.SanitizeForLog(c.Param("task_id"))
+	progress, err := h.kgService.GetChunkImportProgress(ctx, taskID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+	// Task IDs are not tenant scoped; only report tasks of this KB.
+	if progress.KBID != kbID {
+		c.Error(errors.NewNotFoundError("Chunk import task not found"))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    progress,
+	})
+}
+
 // CreateKnowledgeFromURL godoc
 // @Summary      从URL创建知识
 // @Description  从指定URL抓取内容并创建知识条目。当提供 file_name/file_type 或 URL 路径含已知文件扩展名时，自动切换为文件下载模式
@@ -471,6 +661,9 @@ func (h *KnowledgeHandler) CreateKnowledgeFromURL(c *gin.Context) {
 		if h.handleDuplicateKnowledgeError(c, err, knowledge, "url") {
 			return
 		}
+		if h.handleStorageQuotaError(c, err) {
+			return
+		}
 		if appErr, ok := errors.IsAppError(err); ok {
 			c.Error(appErr)
 			return
@@ -532,6 +725,9 @@ func (h *KnowledgeHandler) CreateManualKnowledge(c *gin.Context) {
 
 	knowledge, err := h.kgService.CreateKnowledgeFromManual(ctx, kbID, &req, req.Channel)
 	if err != nil {
+		if h.handleStorageQuotaError(c, err) {
+			return
+		}
 		if appErr, ok := errors.IsAppError(err); ok {
 			c.Error(appErr)
 			return
@@ -598,6 +794,134 @@ func (h *KnowledgeHandler) GetKnowledge(c *gin.Context) {
 	})
 }
 
+// GetKnowledgeJob godoc
+// @Summary      获取知识的解析任务进度
+// @Description  返回该知识当前解析任务的状态（queued/parsing/embedding/indexing/completed/failed/cancelled）、进度百分比、优先级通道与已用重试次数
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "知识ID"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /api/v1/knowledge/{id}/job [get]
+func (h *KnowledgeHandler) GetKnowledgeJob(c *gin.Context) {
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+	knowledge, _, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    h.buildIngestionJob(c.Request.Context(), knowledge),
+	})
+}
+
+// knowledgeJobPollInterval is how often StreamKnowledgeJob re-reads the job.
+const knowledgeJobPollInterval = time.Second
+
+// StreamKnowledgeJob godoc
+// @Summary      订阅知识的解析任务进度（SSE）
+// @Description  以 Server-Sent Events 推送解析任务进度：连接后立即推送一次当前状态，之后每次状态/进度变化推送一条 job 事件，任务结束（completed/failed/cancelled）后关闭连接
+// @Tags         知识管理
+// @Produce      text/event-stream
+// @Param        id   path  string  true  "知识ID"
+// @Success      200  {object}  types.IngestionJob
+// @Router       /api/v1/knowledge/{id}/job/stream [get]
+func (h *KnowledgeHandler) StreamKnowledgeJob(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+	knowledge, _, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	ticker := time.NewTicker(knowledgeJobPollInterval)
+	defer ticker.Stop()
+	var last *types.IngestionJob
+	for {
+		job := h.buildIngestionJob(ctx, knowledge)
+		if last == nil || job.State != last.State || job.Progress != last.Progress ||
+			job.Stage != last.Stage || job.Retry != last.Retry || job.Attempt != last.Attempt {
+			c.SSEvent("job", job)
+			c.Writer.Flush()
+			last = job
+		}
+		if job.Terminal() {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		latest, err := h.kgService.GetKnowledgeByIDOnly(ctx, knowledge.ID)
+		if err != nil && !isKnowledgeNotFound(err) {
+			// A transient lookup failure says nothing about the job; keep
+			// the last state and read it again on the next tick.
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Warnf(ctx, "StreamKnowledgeJob: failed to reload knowledge %s: %v", knowledge.ID, err)
+			continue
+		}
+		if err != nil {
+			// Deleted while streaming: report the job as cancelled.
+			cancelled := *last
+			cancelled.State = types.IngestionJobCancelled
+			cancelled.Stage = ""
+			c.SSEvent("job", &cancelled)
+			c.Writer.Flush()
+			return
+		}
+		knowledge = latest
+	}
+}
+
+// isKnowledgeNotFound reports whether err means the knowledge row is gone.
+func isKnowledgeNotFound(err error) bool {
+	if goerrors.Is(err, repository.ErrKnowledgeNotFound) || goerrors.Is(err, gorm.ErrRecordNotFound) {
+		return true
+	}
+	appErr, ok := errors.IsAppError(err)
+	return ok && appErr.Code == errors.ErrNotFound
+}
+
+// buildIngestionJob assembles the job view from the knowledge row and the
+// spans of its latest attempt. Span lookup failures degrade to the
+// parse_status-only view instead of failing the request.
+func (h *KnowledgeHandler) buildIngestionJob(ctx context.Context, knowledge *types.Knowledge) *types.IngestionJob {
+	var rows []types.KnowledgeProcessingSpan
+	attempt := 0
+	if h.spanRepo != nil {
+		latest, err := h.spanRepo.LatestAttempt(ctx, knowledge.ID)
+		if err != nil {
+			logger.Warnf(ctx, "spans LatestAttempt failed for %s: %v", knowledge.ID, err)
+		} else if latest > 0 {
+			attempt = latest
+			rows, err = h.spanRepo.ListByAttempt(ctx, knowledge.ID, attempt)
+			if err != nil {
+				logger.Warnf(ctx, "spans ListByAttempt failed kid=%s attempt=%d: %v", knowledge.ID, attempt, err)
+				rows = nil
+			}
+		}
+	}
+	return types.BuildIngestionJob(knowledge, attempt, rows)
+}
+
 // GetKnowledgeSpans godoc
 // @Summary      获取知识文档解析的 Span 树（含历史尝试）
 // @Description  返回该知识在解析流水线的 trace tree（root → stage → subspan）：每段状态、耗时、input/output、错误码、langfuse_trace_id。支持 ?attempt=N 查看历史尝试；不传则返回最新尝试。前端用于渲染时间线 + 多模态/embedding 子节点 + 一键跳转 Langfuse。
@@ -1187,14 +1511,77 @@ func (h *KnowledgeHandler) DownloadKnowledgeFile(c *gin.Context) {
 		return
 	}
 
-	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
+	knowledge, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 	logger.Infof(ctx, "Retrieving knowledge file, ID: %s", secutils.SanitizeForLog(id))
 
-	file, filename, err := h.kgService.GetKnowledgeFile(effCtx, id)
+	h.streamKnowledgeFile(c, effCtx, knowledge, func(filename string) {
+		logger.Infof(
+			ctx,
+			"Knowledge file retrieved successfully, ID: %s, filename: %s",
+			secutils.SanitizeForLog(id),
+			secutils.SanitizeForLog(filename),
+		)
+
+		// Set response headers for file download
+		c.Header("Content-Description", "File Transfer")
+		c.Header("Content-Transfer-Encoding", "binary")
+		cd := mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+		c.Header("Content-Disposition", cd)
+		c.Header("Content-Type", "application/octet-stream")
+		c.Header("Expires", "0")
+		c.Header("Cache-Control", "must-revalidate")
+		c.Header("Pragma", "public")
+	})
+}
+
+// streamKnowledgeFile streams the file of knowledge to the response. A
+// single-range Range header is answered with 206 Partial Content so that
+// large PDFs and videos can be played progressively and interrupted
+// downloads resumed; other requests get the whole file. setHeaders is called
+// with the file name before the body is written.
+func (h *KnowledgeHandler) streamKnowledgeFile(c *gin.Context,
+	effCtx context.Context, knowledge *types.Knowledge, setHeaders func(filename string),
+) {
+	ctx := c.Request.Context()
+
+	size := int64(-1)
+	if knowledge.FileSize > 0 {
+		size = knowledge.FileSize
+	}
+	offset, length, ranged := secutils.ParseRangeHeader(c.GetHeader("Range"), size)
+	// No validators are sent, so a conditional range cannot be confirmed to
+	// still match and the whole file is served instead.
+	if c.GetHeader("If-Range") != "" {
+		ranged = false
+	}
+
+	var (
+		file         io.ReadCloser
+		filename     string
+		contentRange string
+		sent         = int64(-1)
+		err          error
+	)
+	if ranged {
+		var total int64
+		file, filename, total, err = h.kgService.GetKnowledgeFileRange(effCtx, knowledge.ID, offset, length)
+		if goerrors.Is(err, filesvc.ErrInvalidRange) {
+			if total >= 0 {
+				c.Header("Content-Range", fmt.Sprintf("bytes */%d", total))
+			}
+			c.AbortWithStatus(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		if err == nil {
+			contentRange, sent = secutils.ContentRange(offset, length, total)
+		}
+	} else {
+		file, filename, err = h.kgService.GetKnowledgeFile(effCtx, knowledge.ID)
+	}
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
 		c.Error(errors.NewInternalServerError("Failed to retrieve file").WithDetails(err.Error()))
@@ -1202,22 +1589,15 @@ func (h *KnowledgeHandler) DownloadKnowledgeFile(c *gin.Context) {
 	}
 	defer file.Close()
 
-	logger.Infof(
-		ctx,
-		"Knowledge file retrieved successfully, ID: %s, filename: %s",
-		secutils.SanitizeForLog(id),
-		secutils.SanitizeForLog(filename),
-	)
-
-	// Set response headers for file download
-	c.Header("Content-Description", "File Transfer")
-	c.Header("Content-Transfer-Encoding", "binary")
-	cd := mime.FormatMediaType("attachment", map[string]string{"filename": filename})
-	c.Header("Content-Disposition", cd)
-	c.Header("Content-Type", "application/octet-stream")
-	c.Header("Expires", "0")
-	c.Header("Cache-Control", "must-revalidate")
-	c.Header("Pragma", "public")
+	setHeaders(filename)
+	c.Header("Accept-Ranges", "bytes")
+	if contentRange != "" {
+		c.Header("Content-Range", contentRange)
+		if sent >= 0 {
+			c.Header("Content-Length", strconv.FormatInt(sent, 10))
+		}
+		c.Status(http.StatusPartialContent)
+	}
 
 	// Stream file content to response
 	c.Stream(func(w io.Writer) bool {
@@ -1290,45 +1670,118 @@ func mimeTypeByExt(filename string) string {
 // @Security     ApiKeyAuth
 // @Router       /knowledge/{id}/preview [get]
 func (h *KnowledgeHandler) PreviewKnowledgeFile(c *gin.Context) {
-	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+
+	knowledge, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 
+	h.streamKnowledgeFile(c, effCtx, knowledge, func(filename string) {
+		c.Header("Content-Type", mimeTypeByExt(filename))
+		c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename}))
+		c.Header("Cache-Control", "private, max-age=3600")
+	})
+}
+
+// GetKnowledgePreviewURL godoc
+// @Summary      获取知识缩略图链接
+// @Description  返回图片或 PDF 知识文件的缩略图（PDF 为首页）链接，供知识列表展示。缩略图在上传后异步生成，尚未生成或不支持的文件返回 404。
+// @Description  存储无法提供直链时（本地存储未配置 APP_EXTERNAL_URL）返回 /knowledge/{id}/thumbnail 接口地址。
+// @Tags         知识管理
+// @Produce      json
+// @Param        id   path      string  true  "知识ID"
+// @Success      200  {object}  map[string]interface{}  "缩略图链接"
+// @Failure      404  {object}  errors.AppError         "缩略图不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/preview-url [get]
+func (h *KnowledgeHandler) GetKnowledgePreviewURL(c *gin.Context) {
+	ctx := c.Request.Context()
 	id := secutils.SanitizeForLog(c.Param("id"))
 	if id == "" {
 		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
 		return
 	}
 
-	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
+	knowledge, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
 	if err != nil {
 		c.Error(err)
 		return
 	}
+	if knowledge.PreviewPath == "" {
+		c.Error(errors.NewNotFoundError("Knowledge has no preview"))
+		return
+	}
 
-	file, filename, err := h.kgService.GetKnowledgeFile(effCtx, id)
+	previewURL, err := h.kgService.GetKnowledgePreviewURL(effCtx, knowledge.ID)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, nil)
-		c.Error(errors.NewInternalServerError("Failed to retrieve file").WithDetails(err.Error()))
+		c.Error(errors.NewInternalServerError("Failed to get preview URL").WithDetails(err.Error()))
 		return
 	}
-	defer file.Close()
-
-	contentType := mimeTypeByExt(filename)
-	c.Header("Content-Type", contentType)
-	c.Header("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": filename}))
-	c.Header("Cache-Control", "private, max-age=3600")
+	if !strings.HasPrefix(previewURL, "http://") && !strings.HasPrefix(previewURL, "https://") {
+		previewURL = "/api/v1/knowledge/" + knowledge.ID + "/thumbnail"
+	}
 
-	c.Stream(func(w io.Writer) bool {
-		if _, err := io.Copy(w, file); err != nil {
-			logger.Errorf(ctx, "Failed to stream preview: %v", err)
-			return false
-		}
-		return false
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"url": previewURL},
 	})
 }
 
-// GetKnowledgeBatchRequest defines parameters for batch knowledge retrieval
-type GetKnowledgeBatchRequest struct {
-	IDs     []string `form:"ids" binding:"required"` // List of knowledge IDs
+// GetKnowledgeThumbnail godoc
+// @Summary      获取知识缩略图
+// @Description  返回图片或 PDF 知识文件的缩略图（JPEG）
+// @Tags         知识管理
+// @Produce      image/jpeg
+// @Param        id   path      string  true  "知识ID"
+// @Success      200  {file}    file    "缩略图"
+// @Failure      404  {object}  errors.AppError  "缩略图不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/thumbnail [get]
+func (h *KnowledgeHandler) GetKnowledgeThumbnail(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+
+	knowledge, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if knowledge.PreviewPath == "" {
+		c.Error(errors.NewNotFoundError("Knowledge has no preview"))
+		return
+	}
+
+	file, err := h.kgService.GetKnowledgePreview(effCtx, knowledge.ID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError("Failed to retrieve preview").WithDetails(err.Error()))
+		return
+	}
+	defer file.Close()
+
+	c.Header("Content-Type", "image/jpeg")
+	c.Header("Cache-Control", "private, max-age=3600")
+	if _, err := io.Copy(c.Writer, file); err != nil {
+		logger.Errorf(ctx, "Failed to send preview: %v", err)
+	}
+}
+
+// GetKnowledgeBatchRequest defines parameters for batch knowledge retrieval
+type GetKnowledgeBatchRequest struct {
+	IDs     []string `form:"ids" binding:"required"` // List of knowledge IDs
 	KBID    string   `form:"kb_id"`                  // Optional: scope to this KB (validates access and uses effective tenant for shared KB)
 	AgentID string   `form:"agent_id"`               // Optional: when using a shared agent, use agent's tenant for retrieval (validates shared agent access)
 }
@@ -1521,6 +1974,58 @@ func (h *KnowledgeHandler) UpdateKnowledge(c *gin.Context) {
 	})
 }
 
+// UpdateKnowledgeACL godoc
+// @Summary      设置知识访问控制列表
+// @Description  设置文档的 ACL（允许的用户和用户组），检索时调用方不在 ACL 中的文档不会被召回；users 和 groups 均为空时移除 ACL
+// @Tags         知识管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                  true  "知识ID"
+// @Param        request  body      types.KnowledgeACL      true  "ACL"
+// @Success      200      {object}  map[string]interface{}  "更新后的知识"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      403      {object}  errors.AppError         "无权限"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/acl [put]
+func (h *KnowledgeHandler) UpdateKnowledgeACL(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	knowledge, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	// ACL groups are roles of the tenant owning the document, so only that
+	// tenant manages its ACLs
+	if knowledge.TenantID != c.GetUint64(types.TenantIDContextKey.String()) {
+		c.Error(errors.NewForbiddenError("只能设置本租户文档的 ACL"))
+		return
+	}
+
+	var acl types.KnowledgeACL
+	if err := c.ShouldBindJSON(&acl); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	updated, err := h.kgService.UpdateKnowledgeACL(effCtx, id, &acl)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_id": id,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updated,
+	})
+}
+
 // UpdateManualKnowledge godoc
 // @Summary      更新手工知识
 // @Description  更新手工录入的Markdown知识内容
@@ -1647,6 +2152,172 @@ func (h *KnowledgeHandler) ReparseKnowledge(c *gin.Context) {
 	})
 }
 
+// UpdateKnowledgeFile godoc
+// @Summary      更新文档文件
+// @Description  上传文件的新版本替换知识的原文件。知识保留ID、分类和元数据，新版本解析完成前仍可检索旧版本；只有内容变化的分块会重新向量化，未变化的分块保留原ID
+// @Tags         知识管理
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id        path      string  true   "知识ID"
+// @Param        file      formData  file    true   "新版本文件"
+// @Param        fileName  formData  string  false  "自定义文件名"
+// @Param        metadata  formData  string  false  "元数据JSON，合并到原有元数据"
+// @Success      200       {object}  map[string]interface{}  "更新后的知识"
+// @Failure      400       {object}  errors.AppError         "请求参数错误"
+// @Failure      409       {object}  errors.AppError         "文档正在处理中"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/file [put]
+func (h *KnowledgeHandler) UpdateKnowledgeFile(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		logger.Error(ctx, "File upload failed", err)
+		c.Error(errors.NewBadRequestError("File upload failed").WithDetails(err.Error()))
+		return
+	}
+	maxSizeMB := utils.GetMaxFileSizeMB()
+	if file.Size > maxSizeMB*1024*1024 {
+		c.Error(errors.NewBadRequestError(fmt.Sprintf("文件大小不能超过%dMB", maxSizeMB)))
+		return
+	}
+	customFileName := secutils.SanitizeForLog(c.PostForm("fileName"))
+
+	var metadata map[string]string
+	if raw := c.PostForm("metadata"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+			c.Error(errors.NewBadRequestError("Invalid metadata format").WithDetails(err.Error()))
+			return
+		}
+	}
+
+	knowledge, err := h.kgService.UpdateKnowledgeFile(effCtx, id, file, customFileName, metadata)
+	if err != nil {
+		if h.handleStorageQuotaError(c, err) {
+			return
+		}
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_id": id,
+		})
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	logger.Infof(ctx, "Knowledge file updated, knowledge ID: %s", id)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    knowledge,
+	})
+}
+
+// ListKnowledgeVersions godoc
+// @Summary      获取文档版本历史
+// @Description  返回通过更新文件产生的文档版本（新版本在前），包括每个版本新增、复用和删除的分块数
+// @Tags         知识管理
+// @Produce      json
+// @Param        id   path      string  true  "知识ID"
+// @Success      200  {object}  map[string]interface{}  "版本列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/versions [get]
+func (h *KnowledgeHandler) ListKnowledgeVersions(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleViewer)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	versions, err := h.kgService.ListKnowledgeVersions(effCtx, id)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_id": id,
+		})
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    versions,
+	})
+}
+
+// RegenerateQuestions godoc
+// @Summary      重新生成问题
+// @Description  使用知识库当前的问题生成配置（数量、模型）为文档的文本分块重新生成问题，替换原有问题及其索引，使用异步任务方式处理
+// @Tags         知识管理
+// @Produce      json
+// @Param        id   path      string  true  "知识ID"
+// @Success      200  {object}  map[string]interface{}  "重新生成任务已提交"
+// @Failure      400  {object}  errors.AppError         "文档未解析完成或未启用问题生成"
+// @Failure      403  {object}  errors.AppError         "权限不足"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/{id}/questions/regenerate [post]
+func (h *KnowledgeHandler) RegenerateQuestions(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("id"))
+	if id == "" {
+		logger.Error(ctx, "Knowledge ID is empty")
+		c.Error(errors.NewBadRequestError("Knowledge ID cannot be empty"))
+		return
+	}
+
+	// Regenerating rewrites chunk metadata and the index: editor permission
+	_, effCtx, err := h.resolveKnowledgeAndValidateKBAccess(c, id, types.OrgRoleEditor)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	chunkCount, err := h.kgService.RegenerateQuestions(effCtx, id)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_id": id,
+		})
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	logger.Infof(ctx, "Question regeneration submitted, knowledge ID: %s, chunks: %d", id, chunkCount)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Question regeneration task submitted",
+		"data":    gin.H{"chunk_count": chunkCount},
+	})
+}
+
 // CancelKnowledgeParse godoc
 // @Summary      取消知识解析
 // @Description  取消进行中的知识解析任务。当前已写入的 chunk / 索引保留，可通过 reparse 接口重新触发解析。已完成 / 已失败 / 删除中的知识不支持取消。
@@ -1996,6 +2667,9 @@ type MoveKnowledgeRequest struct {
 	SourceKBID   string   `json:"source_kb_id"  binding:"required"`
 	TargetKBID   string   `json:"target_kb_id"  binding:"required"`
 	Mode         string   `json:"mode"          binding:"required,oneof=reuse_vectors reparse"`
+	// Dedupe deletes the items whose file already exists in the target
+	// instead of moving them
+	Dedupe bool `json:"dedupe"`
 }
 
 // MoveKnowledgeResponse defines the response for move knowledge
@@ -2031,102 +2705,127 @@ func (h *KnowledgeHandler) MoveKnowledge(c *gin.Context) {
 		return
 	}
 
+	tenantID, ok := h.validateKnowledgeMoveKBs(c, req.SourceKBID, req.TargetKBID, req.Mode)
+	if !ok {
+		return
+	}
+
+	// Validate all knowledge IDs belong to source KB and are in completed status
+	for _, kID := range req.KnowledgeIDs {
+		knowledge, err := h.kgService.GetKnowledgeByID(ctx, kID)
+		if err != nil {
+			c.Error(errors.NewBadRequestError(fmt.Sprintf("Knowledge item %s not found", kID)))
+			return
+		}
+		if knowledge.KnowledgeBaseID != req.SourceKBID {
+			c.Error(errors.NewBadRequestError(fmt.Sprintf("Knowledge item %s does not belong to the source knowledge base", kID)))
+			return
+		}
+		if knowledge.ParseStatus != types.ParseStatusCompleted {
+			c.Error(errors.NewBadRequestError(fmt.Sprintf("Knowledge item %s is not in completed status (current: %s)", kID, knowledge.ParseStatus)))
+			return
+		}
+	}
+
+	payload := types.KnowledgeMovePayload{
+		KnowledgeIDs: req.KnowledgeIDs,
+		SourceKBID:   req.SourceKBID,
+		TargetKBID:   req.TargetKBID,
+		Mode:         req.Mode,
+		Dedupe:       req.Dedupe,
+	}
+	h.enqueueKnowledgeMove(c, tenantID, &payload, len(req.KnowledgeIDs), "Knowledge move task started")
+}
+
+// validateKnowledgeMoveKBs checks that the source and target of a knowledge
+// move or merge are distinct, belong to the caller's tenant and are
+// compatible. On failure the error is attached to c and false is returned.
+func (h *KnowledgeHandler) validateKnowledgeMoveKBs(
+	c *gin.Context, sourceKBID, targetKBID, mode string,
+) (uint64, bool) {
+	ctx := c.Request.Context()
+
 	// Validate source != target
-	if req.SourceKBID == req.TargetKBID {
+	if sourceKBID == targetKBID {
 		c.Error(errors.NewBadRequestError("Source and target knowledge base cannot be the same"))
-		return
+		return 0, false
 	}
 
-	tenantID, exists := c.Get(types.TenantIDContextKey.String())
+	value, exists := c.Get(types.TenantIDContextKey.String())
 	if !exists {
 		c.Error(errors.NewUnauthorizedError("Unauthorized"))
-		return
+		return 0, false
 	}
+	tenantID := value.(uint64)
 
 	// Validate source KB
-	sourceKB, err := h.kbService.GetKnowledgeBaseByID(ctx, req.SourceKBID)
+	sourceKB, err := h.kbService.GetKnowledgeBaseByID(ctx, sourceKBID)
 	if err != nil {
 		if goerrors.Is(err, repository.ErrKnowledgeBaseNotFound) {
 			c.Error(errors.NewNotFoundError("Source knowledge base not found"))
-			return
+			return 0, false
 		}
 		c.Error(errors.NewInternalServerError(err.Error()))
-		return
+		return 0, false
 	}
-	if sourceKB.TenantID != tenantID.(uint64) {
+	if sourceKB.TenantID != tenantID {
 		c.Error(errors.NewForbiddenError("No permission to access source knowledge base"))
-		return
+		return 0, false
 	}
 
 	// Validate target KB
-	targetKB, err := h.kbService.GetKnowledgeBaseByID(ctx, req.TargetKBID)
+	targetKB, err := h.kbService.GetKnowledgeBaseByID(ctx, targetKBID)
 	if err != nil {
 		if goerrors.Is(err, repository.ErrKnowledgeBaseNotFound) {
 			c.Error(errors.NewNotFoundError("Target knowledge base not found"))
-			return
+			return 0, false
 		}
 		c.Error(errors.NewInternalServerError(err.Error()))
-		return
+		return 0, false
 	}
-	if targetKB.TenantID != tenantID.(uint64) {
+	if targetKB.TenantID != tenantID {
 		c.Error(errors.NewForbiddenError("No permission to access target knowledge base"))
-		return
+		return 0, false
 	}
 
 	// Validate type match
 	if sourceKB.Type != targetKB.Type {
 		c.Error(errors.NewBadRequestError("Source and target knowledge bases must be the same type"))
-		return
+		return 0, false
 	}
 
 	// Validate embedding model match
 	if sourceKB.EmbeddingModelID != targetKB.EmbeddingModelID {
 		c.Error(errors.NewBadRequestError("Source and target must use the same embedding model"))
-		return
+		return 0, false
 	}
 
-	// reuse_vectors copies index entries directly between KBs, which only works
-	// inside the same VectorStore backend. A cross-store reuse_vectors move would
-	// route CopyIndices through the SOURCE store and then delete the source
-	// indices, corrupting the vector data. Reject it and point the caller at
-	// reparse mode, which re-indexes into the target store safely.
-	if req.Mode == "reuse_vectors" && !sourceKB.SharesStoreWith(targetKB) {
+	// reuse_vectors keeps the index rows of the source store, which only works
+	// inside the same VectorStore backend; the target KB would otherwise have
+	// no rows in its own store. Reject it and point the caller at reparse
+	// mode, which re-indexes into the target store safely.
+	if mode == "reuse_vectors" && !sourceKB.SharesStoreWith(targetKB) {
 		c.Error(errors.NewBadRequestError(
 			"reuse_vectors move across different vector stores is not supported; " +
 				"use reparse mode to move into a different store"))
-		return
+		return 0, false
 	}
+	return tenantID, true
+}
 
-	// Validate all knowledge IDs belong to source KB and are in completed status
-	for _, kID := range req.KnowledgeIDs {
-		knowledge, err := h.kgService.GetKnowledgeByID(ctx, kID)
-		if err != nil {
-			c.Error(errors.NewBadRequestError(fmt.Sprintf("Knowledge item %s not found", kID)))
-			return
-		}
-		if knowledge.KnowledgeBaseID != req.SourceKBID {
-			c.Error(errors.NewBadRequestError(fmt.Sprintf("Knowledge item %s does not belong to the source knowledge base", kID)))
-			return
-		}
-		if knowledge.ParseStatus != types.ParseStatusCompleted {
-			c.Error(errors.NewBadRequestError(fmt.Sprintf("Knowledge item %s is not in completed status (current: %s)", kID, knowledge.ParseStatus)))
-			return
-		}
-	}
+// enqueueKnowledgeMove enqueues a knowledge move task, records its initial
+// progress and writes the response. count is the number of knowledge items
+// the task is expected to process.
+func (h *KnowledgeHandler) enqueueKnowledgeMove(
+	c *gin.Context, tenantID uint64, payload *types.KnowledgeMovePayload, count int, message string,
+) {
+	ctx := c.Request.Context()
 
 	// Generate task ID
-	taskID := utils.GenerateTaskID("kg_move", tenantID.(uint64), req.SourceKBID)
-
-	// Create move payload
-	payload := types.KnowledgeMovePayload{
-		TenantID:     tenantID.(uint64),
-		TaskID:       taskID,
-		KnowledgeIDs: req.KnowledgeIDs,
-		SourceKBID:   req.SourceKBID,
-		TargetKBID:   req.TargetKBID,
-		Mode:         req.Mode,
-	}
-	langfuse.InjectTracing(ctx, &payload)
+	taskID := utils.GenerateTaskID("kg_move", tenantID, payload.SourceKBID)
+	payload.TenantID = tenantID
+	payload.TaskID = taskID
+	langfuse.InjectTracing(ctx, payload)
 
 	payloadBytes, err := json.Marshal(payload)
 	if err != nil {
@@ -2146,15 +2845,15 @@ func (h *KnowledgeHandler) MoveKnowledge(c *gin.Context) {
 	}
 
 	logger.Infof(ctx, "MoveKnowledge: task enqueued: %s, asynq_id: %s, source: %s, target: %s, count: %d",
-		taskID, info.ID, secutils.SanitizeForLog(req.SourceKBID), secutils.SanitizeForLog(req.TargetKBID), len(req.KnowledgeIDs))
+		taskID, info.ID, secutils.SanitizeForLog(payload.SourceKBID), secutils.SanitizeForLog(payload.TargetKBID), count)
 
 	// Save initial progress
 	initialProgress := &types.KnowledgeMoveProgress{
 		TaskID:     taskID,
-		SourceKBID: req.SourceKBID,
-		TargetKBID: req.TargetKBID,
+		SourceKBID: payload.SourceKBID,
+		TargetKBID: payload.TargetKBID,
 		Status:     types.KBCloneStatusPending,
-		Total:      len(req.KnowledgeIDs),
+		Total:      count,
 		Progress:   0,
 		Message:    "Task queued, waiting to start...",
 		CreatedAt:  time.Now().Unix(),
@@ -2168,14 +2867,71 @@ func (h *KnowledgeHandler) MoveKnowledge(c *gin.Context) {
 		"success": true,
 		"data": MoveKnowledgeResponse{
 			TaskID:         taskID,
-			SourceKBID:     req.SourceKBID,
-			TargetKBID:     req.TargetKBID,
-			KnowledgeCount: len(req.KnowledgeIDs),
-			Message:        "Knowledge move task started",
+			SourceKBID:     payload.SourceKBID,
+			TargetKBID:     payload.TargetKBID,
+			KnowledgeCount: count,
+			Message:        message,
 		},
 	})
 }
 
+// MergeKnowledgeBaseRequest defines the request for merging knowledge bases
+type MergeKnowledgeBaseRequest struct {
+	SourceKBID string `json:"source_kb_id" binding:"required"`
+	TargetKBID string `json:"target_kb_id" binding:"required"`
+	Mode       string `json:"mode"         binding:"required,oneof=reuse_vectors reparse"`
+}
+
+// MergeKnowledgeBase merges one knowledge base into another (async task).
+//
+// MergeKnowledgeBase godoc
+// @Summary      合并知识库
+// @Description  将源知识库中的全部知识移动到目标知识库（异步）。文件哈希与目标知识库中已有知识相同的条目不移动，直接从源知识库删除；未解析完成的知识保留在源知识库并计入失败数。进度通过知识移动进度接口查询
+// @Tags         知识
+// @Accept       json
+// @Produce      json
+// @Param        request  body      handler.MergeKnowledgeBaseRequest  true  "{source_kb_id, target_kb_id, mode}"
+// @Success      200      {object}  handler.MoveKnowledgeResponse      "任务信息"
+// @Failure      400      {object}  errors.AppError                    "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge/merge [post]
+func (h *KnowledgeHandler) MergeKnowledgeBase(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req MergeKnowledgeBaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "MergeKnowledgeBase: failed to parse request", err)
+		c.Error(errors.NewBadRequestError("Invalid request parameters: " + err.Error()))
+		return
+	}
+
+	tenantID, ok := h.validateKnowledgeMoveKBs(c, req.SourceKBID, req.TargetKBID, req.Mode)
+	if !ok {
+		return
+	}
+
+	count, err := h.kgService.GetRepository().CountKnowledgeByKnowledgeBaseID(ctx, tenantID, req.SourceKBID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+	if count == 0 {
+		c.Error(errors.NewBadRequestError("Source knowledge base is empty"))
+		return
+	}
+
+	payload := types.KnowledgeMovePayload{
+		SourceKBID: req.SourceKBID,
+		TargetKBID: req.TargetKBID,
+		Mode:       req.Mode,
+		Merge:      true,
+		Dedupe:     true,
+	}
+	h.enqueueKnowledgeMove(c, tenantID, &payload, int(count), "Knowledge base merge task started")
+}
+
 // GetKnowledgeMoveProgress retrieves the progress of a knowledge move task.
 //
 // GetKnowledgeMoveProgress godoc
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// KnowledgeGapHandler exposes the knowledge gap report of a knowledge base.
+// KB access is checked by the route-level KBAccessWrite guard.
+type KnowledgeGapHandler struct {
+	gapService interfaces.KnowledgeGapService
+}
+
+// NewKnowledgeGapHandler creates a new KnowledgeGapHandler.
+func NewKnowledgeGapHandler(gapService interfaces.KnowledgeGapService) *KnowledgeGapHandler {
+	return &KnowledgeGapHandler{gapService: gapService}
+}
+
+// GetGapReport godoc
+// @Summary      获取知识缺口报告
+// @Description  列出在该知识库中检索不到任何结果的提问，按语义相似度聚类，出现次数最多的在前，用于发现需要补充的文档
+// @Tags         知识缺口
+// @Produce      json
+// @Param        id         path      string                    true   "知识库ID"
+// @Param        threshold  query     number                    false  "聚类的相似度阈值，0.5 到 1，默认 0.85"
+// @Param        limit      query     int                       false  "返回的聚类数，默认 20，最多 100"
+// @Success      200        {object}  types.KnowledgeGapReport  "知识缺口报告"
+// @Failure      400        {object}  errors.AppError           "请求参数错误"
+// @Failure      404        {object}  errors.AppError           "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/knowledge-gaps [get]
+func (h *KnowledgeGapHandler) GetGapReport(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.KnowledgeGapReportRequest
+	if threshold := c.Query("threshold"); threshold != "" {
+		value, err := strconv.ParseFloat(threshold, 64)
+		if err != nil {
+			c.Error(apperrors.NewBadRequestError("threshold 必须是数字"))
+			return
+		}
+		req.Threshold = value
+	}
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			c.Error(apperrors.NewBadRequestError("limit 必须是整数"))
+			return
+		}
+		req.Limit = value
+	}
+
+	report, err := h.gapService.GetGapReport(ctx, kbID, &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// DismissGaps godoc
+// @Summary      忽略知识缺口
+// @Description  补充文档后删除知识缺口；之后仍检索不到结果的提问会重新记录
+// @Tags         知识缺口
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                            true  "知识库ID"
+// @Param        request  body      types.KnowledgeGapDismissRequest  true  "要忽略的知识缺口"
+// @Success      200      {object}  map[string]interface{}            "删除的数量"
+// @Failure      400      {object}  errors.AppError                   "请求参数错误"
+// @Failure      404      {object}  errors.AppError                   "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/knowledge-gaps/dismiss [post]
+func (h *KnowledgeGapHandler) DismissGaps(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.KnowledgeGapDismissRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	deleted, err := h.gapService.DismissGaps(ctx, kbID, req.GapIDs)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"deleted": deleted},
+	})
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/Tencent/WeKnora/internal/application/repository"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+)
+
+// StreamKnowledgeJob reports a job as cancelled only when its knowledge was
+// deleted; any other lookup failure must not end a running job's stream.
+func TestIsKnowledgeNotFound(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"repository sentinel", repository.ErrKnowledgeNotFound, true},
+		{"wrapped sentinel", fmt.Errorf("get knowledge: %w", repository.ErrKnowledgeNotFound), true},
+		{"gorm not found", gorm.ErrRecordNotFound, true},
+		{"app not found", apperrors.NewNotFoundError("knowledge not found"), true},
+		{"context cancelled", context.Canceled, false},
+		{"db error", stderrors.New("connection reset by peer"), false},
+		{"app internal error", apperrors.NewInternalServerError("boom"), false},
+	}
+	for _, tc := range cases {
+		if got := isKnowledgeNotFound(tc.err); got != tc.want {
+			t.Errorf("%s: isKnowledgeNotFound = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
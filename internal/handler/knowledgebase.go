@@ -35,6 +35,8 @@ type KnowledgeBaseHandler struct {
 	// userService 仅在 list 类接口里用于批量回填 creator_name；
 	// 真正的鉴权由 RBAC 中间件 + Lookup 完成，这里不参与决策。
 	userService interfaces.UserService
+	// tagScopeService 把标签访问规则应用到直接检索接口上
+	tagScopeService interfaces.TagScopeService
 }
 
 // NewKnowledgeBaseHandler creates a new knowledge base handler instance
@@ -46,6 +48,7 @@ func NewKnowledgeBaseHandler(
 	asynqClient interfaces.TaskEnqueuer,
 	vectorStoreService interfaces.VectorStoreService,
 	userService interfaces.UserService,
+	tagScopeService interfaces.TagScopeService,
 ) *KnowledgeBaseHandler {
 	return &KnowledgeBaseHandler{
 		service:            service,
@@ -55,6 +58,7 @@ func NewKnowledgeBaseHandler(
 		asynqClient:        asynqClient,
 		vectorStoreService: vectorStoreService,
 		userService:        userService,
+		tagScopeService:    tagScopeService,
 	}
 }
 
@@ -306,8 +310,22 @@ func (h *KnowledgeBaseHandler) HybridSearch(c *gin.Context) {
 	logger.Infof(ctx, "Executing hybrid search, knowledge base ID: %s, query: %s, effectiveTenantID: %d",
 		secutils.SanitizeForLog(id), secutils.SanitizeForLog(req.QueryText), effectiveTenantID)
 
+	searchable, err := h.applyTagScope(ctx, id, effectiveTenantID, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !searchable {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data":    []*types.SearchResult{},
+		})
+		return
+	}
+
 	// Execute hybrid search with default search parameters
 	// Note: For shared KBs, the service uses effectiveTenantID internally via context
+	ctx, statusRecorder := types.WithRetrievalStatusRecorder(ctx)
 	results, err := h.service.HybridSearch(ctx, id, req)
 	if err != nil {
 		// Service-layer typed AppErrors (e.g. ErrVectorStoreBindingInvalid,
@@ -326,10 +344,68 @@ func (h *KnowledgeBaseHandler) HybridSearch(c *gin.Context) {
 
 	logger.Infof(ctx, "Hybrid search completed, knowledge base ID: %s, result count: %d",
 		secutils.SanitizeForLog(id), len(results))
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"success": true,
 		"data":    results,
-	})
+	}
+	// Only degraded searches carry a status so healthy responses keep
+	// their historical shape.
+	if status := statusRecorder.Status(); status.Degraded {
+		resp["retrieval_status"] = status
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// applyTagScope expands the tag filter of a hybrid search to descendant tags
+// and narrows it to the tag subtrees the caller may access. It reports false
+// when nothing is left to search.
+func (h *KnowledgeBaseHandler) applyTagScope(
+	ctx context.Context,
+	id string,
+	tenantID uint64,
+	req *types.SearchParams,
+) (bool, error) {
+	if h.tagScopeService == nil {
+		return true, nil
+	}
+	kbIDs := req.KnowledgeBaseIDs
+	if len(kbIDs) == 0 {
+		kbIDs = []string{id}
+	}
+	targets := make(types.SearchTargets, 0, len(kbIDs))
+	for _, kbID := range kbIDs {
+		target := &types.SearchTarget{
+			Type:            types.SearchTargetTypeKnowledgeBase,
+			KnowledgeBaseID: kbID,
+			TenantID:        tenantID,
+			KnowledgeIDs:    req.KnowledgeIDs,
+			TagIDs:          req.TagIDs,
+		}
+		if len(req.KnowledgeIDs) > 0 {
+			target.Type = types.SearchTargetTypeKnowledge
+		}
+		targets = append(targets, target)
+	}
+	scoped, err := h.tagScopeService.ScopeSearchTargets(ctx, targets)
+	if err != nil {
+		return false, err
+	}
+	if len(kbIDs) == 1 {
+		if len(scoped) == 0 {
+			return false, nil
+		}
+		req.KnowledgeIDs = scoped[0].KnowledgeIDs
+		req.TagIDs = scoped[0].TagIDs
+		return true, nil
+	}
+	// One set of filters applies to every knowledge base of a multi-KB
+	// search, so it cannot carry a different tag scope per knowledge base.
+	for i, target := range scoped {
+		if len(scoped) != len(targets) || target != targets[i] {
+			return false, apperrors.NewForbiddenError("标签访问规则限制了部分知识库，请按知识库分别检索")
+		}
+	}
+	return true, nil
 }
 
 // CreateKnowledgeBase godoc
@@ -1262,3 +1338,102 @@ func (h *KnowledgeBaseHandler) ListMoveTargets(c *gin.Context) {
 		"data":    targets,
 	})
 }
+
+// MigrateKnowledgeBaseFiles moves the knowledge base's stored files to
+// another storage provider configured for the tenant.
+//
+// MigrateKnowledgeBaseFiles godoc
+// @Summary      迁移知识库文件存储
+// @Description  将知识库下的文件复制到租户已配置的另一存储（如从 local 迁移到 tos），逐个校验 SHA-256 后在同一事务中改写文件路径并切换知识库存储；任一文件失败则不做任何改动
+// @Tags         知识库
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                      true  "知识库 ID"
+// @Param        request  body      types.FileMigrationRequest  true  "迁移参数"
+// @Success      200      {object}  map[string]interface{}      "迁移结果"
+// @Failure      400      {object}  errors.AppError             "目标存储未配置"
+// @Failure      404      {object}  errors.AppError             "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/migrate-files [post]
+func (h *KnowledgeBaseHandler) MigrateKnowledgeBaseFiles(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := c.Param("id")
+	if id == "" {
+		c.Error(apperrors.NewBadRequestError("Knowledge base ID is required"))
+		return
+	}
+
+	var req types.FileMigrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(apperrors.NewBadRequestError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	result, err := h.knowledgeService.MigrateKnowledgeFiles(ctx, id, &req)
+	if err != nil {
+		if stderrors.Is(err, repository.ErrKnowledgeBaseNotFound) {
+			c.Error(apperrors.NewNotFoundError("Knowledge base not found"))
+			return
+		}
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// ReencryptKnowledgeBaseFiles rewrites the knowledge base's stored files under
+// the storage's current server-side encryption key.
+//
+// ReencryptKnowledgeBaseFiles godoc
+// @Summary      重新加密知识库文件
+// @Description  使用存储当前配置的服务端加密密钥（如轮换后的 KMS 密钥）重写知识库下所有尚未使用该密钥加密的文件
+// @Tags         知识库
+// @Produce      json
+// @Param        id   path      string                  true  "知识库 ID"
+// @Success      200  {object}  map[string]interface{}  "重新加密结果"
+// @Failure      400  {object}  errors.AppError         "存储未启用服务端加密"
+// @Failure      404  {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /knowledge-bases/{id}/reencrypt-files [post]
+func (h *KnowledgeBaseHandler) ReencryptKnowledgeBaseFiles(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := c.Param("id")
+	if id == "" {
+		c.Error(apperrors.NewBadRequestError("Knowledge base ID is required"))
+		return
+	}
+
+	result, err := h.knowledgeService.ReencryptKnowledgeFiles(ctx, id)
+	if err != nil {
+		if stderrors.Is(err, repository.ErrKnowledgeBaseNotFound) {
+			c.Error(apperrors.NewNotFoundError("Knowledge base not found"))
+			return
+		}
+		if appErr, ok := apperrors.IsAppError(err); ok {
+			c.Error(appErr)
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
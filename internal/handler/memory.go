@@ -0,0 +1,225 @@
+package handler
+
+import (
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	"github.com/gin-gonic/gin"
+)
+
+// MemoryHandler lets users inspect and correct what the memory feature
+// remembers about them: profile facts, conversation episodes and the
+// entities and relationships extracted from them.
+//
+// Like favorites, memories are personal: every endpoint is scoped to the
+// calling user and there is no way to address another user's memory.
+type MemoryHandler struct {
+	service interfaces.MemoryService
+}
+
+func NewMemoryHandler(svc interfaces.MemoryService) *MemoryHandler {
+	return &MemoryHandler{service: svc}
+}
+
+// memoryUserID resolves the calling user.
+func memoryUserID(c *gin.Context) (string, bool) {
+	userID := c.GetString(types.UserIDContextKey.String())
+	if userID == "" {
+		c.Error(apperrors.NewUnauthorizedError("user ID not found"))
+		return "", false
+	}
+	return userID, true
+}
+
+// memoryError maps memory service errors onto API errors.
+func memoryError(c *gin.Context, err error) {
+	switch {
+	case stderrors.Is(err, types.ErrMemoryUnavailable):
+		c.Error(apperrors.NewServiceUnavailableError("memory is not enabled on this server"))
+	case stderrors.Is(err, types.ErrMemoryNotFound):
+		c.Error(apperrors.NewNotFoundError(err.Error()))
+	default:
+		logger.ErrorWithFields(c.Request.Context(), err, nil)
+		c.Error(apperrors.NewInternalServerError(err.Error()))
+	}
+}
+
+// GetProfile godoc
+// @Summary      Get my memory profile
+// @Description  Lists the stable facts remembered about the user
+// @Tags         Memory
+// @Success      200  {object}  map[string]interface{}
+// @Router       /user/memory/profile [get]
+func (h *MemoryHandler) GetProfile(c *gin.Context) {
+	userID, ok := memoryUserID(c)
+	if !ok {
+		return
+	}
+	profile, err := h.service.GetUserProfile(c.Request.Context(), userID)
+	if err != nil {
+		memoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": profile})
+}
+
+// DeleteProfileFact godoc
+// @Summary      Forget a profile fact
+// @Tags         Memory
+// @Param        category  path      string  true  "Fact category"
+// @Param        key       path      string  true  "Fact key"
+// @Success      200       {object}  map[string]interface{}
+// @Router       /user/memory/profile/{category}/{key} [delete]
+func (h *MemoryHandler) DeleteProfileFact(c *gin.Context) {
+	userID, ok := memoryUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteProfileFact(c.Request.Context(), userID, c.Param("category"), c.Param("key")); err != nil {
+		memoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ListEpisodes godoc
+// @Summary      List my memory episodes
+// @Description  Lists the remembered conversation episodes, newest first, archived ones included
+// @Tags         Memory
+// @Param        page       query     int  false  "Page number"
+// @Param        page_size  query     int  false  "Page size"
+// @Success      200        {object}  map[string]interface{}
+// @Router       /user/memory/episodes [get]
+func (h *MemoryHandler) ListEpisodes(c *gin.Context) {
+	userID, ok := memoryUserID(c)
+	if !ok {
+		return
+	}
+	var page types.Pagination
+	if err := c.ShouldBindQuery(&page); err != nil {
+		c.Error(apperrors.NewBadRequestError("invalid pagination").WithDetails(err.Error()))
+		return
+	}
+	result, err := h.service.ListEpisodes(c.Request.Context(), userID, &page)
+	if err != nil {
+		memoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
+}
+
+// DeleteEpisode godoc
+// @Summary      Forget a memory episode
+// @Description  Deletes the episode and the entities only it mentions
+// @Tags         Memory
+// @Param        id   path      string  true  "Episode ID"
+// @Success      200  {object}  map[string]interface{}
+// @Router       /user/memory/episodes/{id} [delete]
+func (h *MemoryHandler) DeleteEpisode(c *gin.Context) {
+	userID, ok := memoryUserID(c)
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteEpisode(c.Request.Context(), userID, c.Param("id")); err != nil {
+		memoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ListEntities godoc
+// @Summary      List my memory entities
+// @Tags         Memory
+// @Success      200  {object}  map[string]interface{}
+// @Router       /user/memory/entities [get]
+func (h *MemoryHandler) ListEntities(c *gin.Context) {
+	userID, ok := memoryUserID(c)
+	if !ok {
+		return
+	}
+	entities, err := h.service.ListEntities(c.Request.Context(), userID)
+	if err != nil {
+		memoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": entities})
+}
+
+// UpdateMemoryEntityRequest identifies an entity by name, which may contain
+// any character and so travels in the body rather than the path.
+type UpdateMemoryEntityRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// UpdateEntity godoc
+// @Summary      Correct a memory entity
+// @Description  Sets the type and description of an entity the user's episodes mention
+// @Tags         Memory
+// @Param        body  body      UpdateMemoryEntityRequest  true  "Entity"
+// @Success      200   {object}  map[string]interface{}
+// @Router       /user/memory/entities [put]
+func (h *MemoryHandler) UpdateEntity(c *gin.Context) {
+	userID, ok := memoryUserID(c)
+	if !ok {
+		return
+	}
+	var req UpdateMemoryEntityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperrors.NewBadRequestError("invalid request body").WithDetails(err.Error()))
+		return
+	}
+	entity := &types.MemoryEntity{Name: req.Name, Type: req.Type, Description: req.Description}
+	if err := h.service.UpdateEntity(c.Request.Context(), userID, entity); err != nil {
+		memoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": entity})
+}
+
+// ListRelationships godoc
+// @Summary      List my memory relationships
+// @Tags         Memory
+// @Success      200  {object}  map[string]interface{}
+// @Router       /user/memory/relationships [get]
+func (h *MemoryHandler) ListRelationships(c *gin.Context) {
+	userID, ok := memoryUserID(c)
+	if !ok {
+		return
+	}
+	relationships, err := h.service.ListRelationships(c.Request.Context(), userID)
+	if err != nil {
+		memoryError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": relationships})
+}
+
+// ExportMemory godoc
+// @Summary      Export my memory
+// @Description  Downloads the user's profile, episodes, entities and relationships as JSON
+// @Tags         Memory
+// @Produce      json
+// @Success      200  {object}  types.MemoryExport
+// @Router       /user/memory/export [get]
+func (h *MemoryHandler) ExportMemory(c *gin.Context) {
+	userID, ok := memoryUserID(c)
+	if !ok {
+		return
+	}
+	export, err := h.service.ExportMemory(c.Request.Context(), userID)
+	if err != nil {
+		memoryError(c, err)
+		return
+	}
+	filename := fmt.Sprintf("memory-%s.json", time.Now().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.IndentedJSON(http.StatusOK, export)
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/models/probe"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// ModelProbeRequest 模型能力探测请求：在测试连接请求的基础上指定模型类型，
+// 并可开启上下文长度实测。
+type ModelProbeRequest struct {
+	ModelTestRequest
+	// Type 为 KnowledgeQA（对话模型）或 Embedding
+	Type types.ModelType `json:"type" binding:"required"`
+	// ProbeContext 为 true 时，服务未上报上下文长度的对话模型会发送长提示词实测，消耗较多 token
+	ProbeContext bool `json:"probeContext,omitempty"`
+	// MaxContextTokens 是上下文长度实测的上限，默认 32768，最大 131072
+	MaxContextTokens int `json:"maxContextTokens,omitempty"`
+}
+
+// ProbeModel godoc
+// @Summary      探测模型能力
+// @Description  使用尚未保存的模型配置实际调用模型，返回结构化的探测报告：对话模型检查对话往返、流式输出、
+// @Description  工具调用支持与上下文长度，Embedding 模型检查连通性并探测向量维度
+// @Tags         初始化
+// @Accept       json
+// @Produce      json
+// @Param        request  body      handler.ModelProbeRequest  true  "探测请求"
+// @Success      200      {object}  probe.Report               "探测报告"
+// @Failure      400      {object}  errors.AppError            "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /initialization/models/probe [post]
+func (h *InitializationHandler) ProbeModel(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req ModelProbeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse model probe request", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if req.Type != types.ModelTypeKnowledgeQA && req.Type != types.ModelTypeEmbedding {
+		c.Error(errors.NewBadRequestError("type 仅支持 KnowledgeQA 或 Embedding"))
+		return
+	}
+	if req.MaxContextTokens < 0 {
+		c.Error(errors.NewBadRequestError("maxContextTokens 不能为负数"))
+		return
+	}
+	h.fillSecretsFromStoredModel(ctx, &req.ModelTestRequest)
+
+	source := types.ModelSource(strings.ToLower(req.Source))
+	if source == "" {
+		source = types.ModelSourceRemote
+	}
+	if source == types.ModelSourceRemote && req.BaseURL == "" {
+		c.Error(errors.NewBadRequestError("Base URL不能为空"))
+		return
+	}
+	if req.BaseURL != "" {
+		if err := utils.ValidateURLForSSRF(req.BaseURL); err != nil {
+			logger.Warnf(ctx, "SSRF validation failed for probed model BaseURL: %v", err)
+			c.Error(errors.NewBadRequestError(utils.FormatSSRFError("Base URL", req.BaseURL, err)))
+			return
+		}
+	}
+	appID, appSecret, ok := h.resolveTenantWeKnoraCloudCreds(ctx)
+	if !ok {
+		logger.Error(ctx, "Tenant info not found")
+		c.Error(errors.NewBadRequestError("租户信息未找到"))
+		return
+	}
+
+	logger.Infof(ctx, "Probing %s model %s", req.Type, utils.SanitizeForLog(req.ModelName))
+	model := h.buildTestModel(&req.ModelTestRequest, req.Type, types.ModelSourceRemote)
+	opts := probe.Options{ProbeContext: req.ProbeContext, MaxContextTokens: req.MaxContextTokens}
+
+	var report *probe.Report
+	switch req.Type {
+	case types.ModelTypeEmbedding:
+		emb, err := embedding.NewEmbedder(embedding.ConfigFromModel(model, appID, appSecret), h.pooler, h.ollamaService)
+		if err != nil {
+			report = probeSetupFailed(req.ModelName, req.Type, err)
+			break
+		}
+		report = probe.ProbeEmbedding(ctx, emb, opts)
+	default:
+		chatInstance, err := chat.NewChat(chat.ConfigFromModel(model, appID, appSecret), h.ollamaService)
+		if err != nil {
+			report = probeSetupFailed(req.ModelName, req.Type, err)
+			break
+		}
+		report = probe.ProbeChat(ctx, chatInstance, opts)
+	}
+
+	logger.Infof(ctx, "Model probe completed, available: %v, duration: %dms", report.Available, report.DurationMs)
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// probeSetupFailed 构造模型实例创建失败时的报告
+func probeSetupFailed(modelName string, modelType types.ModelType, err error) *probe.Report {
+	failed := probe.Check{Status: probe.StatusFailed, Message: "创建模型实例失败: " + err.Error()}
+	report := &probe.Report{ModelName: modelName, ModelType: modelType}
+	if modelType == types.ModelTypeEmbedding {
+		report.Embedding = &probe.EmbeddingCheck{Check: failed}
+	} else {
+		report.Chat = &failed
+	}
+	return report
+}
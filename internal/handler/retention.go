@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// RetentionHandler manages the data retention policies of a tenant and its
+// knowledge bases. Only tenant admins reach it; the route-level guards
+// check the role.
+type RetentionHandler struct {
+	retentionService interfaces.RetentionService
+}
+
+// NewRetentionHandler creates a new RetentionHandler.
+func NewRetentionHandler(retentionService interfaces.RetentionService) *RetentionHandler {
+	return &RetentionHandler{retentionService: retentionService}
+}
+
+// GetTenantPolicy godoc
+// @Summary      获取租户数据保留策略
+// @Description  获取会话、消息与记忆的保留策略；未配置时返回默认的关闭状态
+// @Tags         数据保留
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "保留策略"
+// @Security     Bearer
+// @Router       /retention-policy [get]
+func (h *RetentionHandler) GetTenantPolicy(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	policy, err := h.retentionService.GetTenantPolicy(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    policy,
+	})
+}
+
+// UpdateTenantPolicy godoc
+// @Summary      更新租户数据保留策略
+// @Description  设置会话与消息、记忆的保留天数，0 表示永久保留；启用后首次清理在一天后执行
+// @Tags         数据保留
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.RetentionPolicyRequest  true  "保留策略"
+// @Success      200      {object}  map[string]interface{}        "保留策略"
+// @Failure      400      {object}  errors.AppError               "请求参数错误"
+// @Security     Bearer
+// @Router       /retention-policy [put]
+func (h *RetentionHandler) UpdateTenantPolicy(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.RetentionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to bind retention policy payload", err)
+		c.Error(apperrors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	policy, err := h.retentionService.UpdateTenantPolicy(ctx, &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    policy,
+	})
+}
+
+// GetKnowledgeBasePolicy godoc
+// @Summary      获取知识库数据保留策略
+// @Description  获取知识库文档的保留策略；未配置时返回默认的关闭状态
+// @Tags         数据保留
+// @Produce      json
+// @Param        id   path      string                  true  "知识库 ID"
+// @Success      200  {object}  map[string]interface{}  "保留策略"
+// @Failure      404  {object}  errors.AppError         "知识库不存在"
+// @Security     Bearer
+// @Router       /knowledge-bases/{id}/retention-policy [get]
+func (h *RetentionHandler) GetKnowledgeBasePolicy(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	policy, err := h.retentionService.GetKnowledgeBasePolicy(ctx, kbID)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_base_id": kbID,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    policy,
+	})
+}
+
+// UpdateKnowledgeBasePolicy godoc
+// @Summary      更新知识库数据保留策略
+// @Description  设置文档的保留天数或过期日期，早于截止时间创建的文档会被删除
+// @Tags         数据保留
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                        true  "知识库 ID"
+// @Param        request  body      types.RetentionPolicyRequest  true  "保留策略"
+// @Success      200      {object}  map[string]interface{}        "保留策略"
+// @Failure      400      {object}  errors.AppError               "请求参数错误"
+// @Failure      404      {object}  errors.AppError               "知识库不存在"
+// @Security     Bearer
+// @Router       /knowledge-bases/{id}/retention-policy [put]
+func (h *RetentionHandler) UpdateKnowledgeBasePolicy(c *gin.Context) {
+	ctx := c.Request.Context()
+	kbID := secutils.SanitizeForLog(c.Param("id"))
+
+	var req types.RetentionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to bind retention policy payload", err)
+		c.Error(apperrors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	policy, err := h.retentionService.UpdateKnowledgeBasePolicy(ctx, kbID, &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"knowledge_base_id": kbID,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    policy,
+	})
+}
+
+// PreviewRetention godoc
+// @Summary      预览数据保留清理
+// @Description  按当前配置统计每条保留策略将删除的会话、消息、记忆与文档数量，不实际删除；未启用的策略也会预览
+// @Tags         数据保留
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "各策略的清理预览"
+// @Security     Bearer
+// @Router       /retention-policy/preview [post]
+func (h *RetentionHandler) PreviewRetention(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	reports, err := h.retentionService.PreviewPolicies(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    reports,
+	})
+}
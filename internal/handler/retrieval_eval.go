@@ -0,0 +1,373 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// maxEvalDatasetFileSize bounds an uploaded dataset file
+const maxEvalDatasetFileSize = 10 << 20
+
+// RetrievalEvalHandler manages golden datasets and the evaluation runs that
+// measure retrieval and answer quality of a knowledge base against them.
+type RetrievalEvalHandler struct {
+	evalService interfaces.RetrievalEvalService
+}
+
+// NewRetrievalEvalHandler creates a new RetrievalEvalHandler.
+func NewRetrievalEvalHandler(evalService interfaces.RetrievalEvalService) *RetrievalEvalHandler {
+	return &RetrievalEvalHandler{evalService: evalService}
+}
+
+// CreateDataset godoc
+// @Summary      创建评测数据集
+// @Description  上传问题、期望来源与参考答案组成的黄金数据集，最多 1000 条
+// @Tags         检索评测
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.EvalDatasetRequest  true  "评测数据集"
+// @Success      200      {object}  map[string]interface{}    "评测数据集"
+// @Failure      400      {object}  errors.AppError           "请求参数错误"
+// @Security     Bearer
+// @Router       /eval-datasets [post]
+func (h *RetrievalEvalHandler) CreateDataset(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.EvalDatasetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to bind evaluation dataset payload", err)
+		c.Error(apperrors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+	h.createDataset(c, &req)
+}
+
+// UploadDataset godoc
+// @Summary      上传评测数据集文件
+// @Description  上传 JSON Lines（每行一条）或 JSON 数组格式的数据集文件，文件不超过 10MB
+// @Tags         检索评测
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file         formData  file    true   "数据集文件"
+// @Param        name         formData  string  true   "数据集名称"
+// @Param        description  formData  string  false  "数据集描述"
+// @Success      200          {object}  map[string]interface{}  "评测数据集"
+// @Failure      400          {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Router       /eval-datasets/upload [post]
+func (h *RetrievalEvalHandler) UploadDataset(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		logger.Error(ctx, "Evaluation dataset upload failed", err)
+		c.Error(apperrors.NewBadRequestError("文件上传失败").WithDetails(err.Error()))
+		return
+	}
+	if file.Size > maxEvalDatasetFileSize {
+		c.Error(apperrors.NewBadRequestError("数据集文件不能超过 10MB"))
+		return
+	}
+	f, err := file.Open()
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError("文件上传失败").WithDetails(err.Error()))
+		return
+	}
+	defer f.Close()
+	data, err := io.ReadAll(io.LimitReader(f, maxEvalDatasetFileSize))
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError("文件上传失败").WithDetails(err.Error()))
+		return
+	}
+	items, err := types.ParseEvalDatasetItems(data)
+	if err != nil {
+		c.Error(apperrors.NewBadRequestError("数据集文件格式不正确").WithDetails(err.Error()))
+		return
+	}
+
+	h.createDataset(c, &types.EvalDatasetRequest{
+		Name:        c.PostForm("name"),
+		Description: c.PostForm("description"),
+		Items:       items,
+	})
+}
+
+func (h *RetrievalEvalHandler) createDataset(c *gin.Context, req *types.EvalDatasetRequest) {
+	ctx := c.Request.Context()
+
+	dataset, err := h.evalService.CreateDataset(ctx, req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dataset,
+	})
+}
+
+// ListDatasets godoc
+// @Summary      获取评测数据集列表
+// @Description  获取当前租户的评测数据集，不含条目
+// @Tags         检索评测
+// @Produce      json
+// @Success      200  {object}  map[string]interface{}  "评测数据集列表"
+// @Security     Bearer
+// @Router       /eval-datasets [get]
+func (h *RetrievalEvalHandler) ListDatasets(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	datasets, err := h.evalService.ListDatasets(ctx)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    datasets,
+	})
+}
+
+// GetDataset godoc
+// @Summary      获取评测数据集详情
+// @Description  获取评测数据集及其全部条目
+// @Tags         检索评测
+// @Produce      json
+// @Param        id   path      string                  true  "数据集 ID"
+// @Success      200  {object}  map[string]interface{}  "评测数据集"
+// @Failure      404  {object}  errors.AppError         "数据集不存在"
+// @Security     Bearer
+// @Router       /eval-datasets/{id} [get]
+func (h *RetrievalEvalHandler) GetDataset(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+
+	dataset, err := h.evalService.GetDataset(ctx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"dataset_id": id,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dataset,
+	})
+}
+
+// DeleteDataset godoc
+// @Summary      删除评测数据集
+// @Description  删除评测数据集及其全部评测运行
+// @Tags         检索评测
+// @Produce      json
+// @Param        id   path      string                  true  "数据集 ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      404  {object}  errors.AppError         "数据集不存在"
+// @Security     Bearer
+// @Router       /eval-datasets/{id} [delete]
+func (h *RetrievalEvalHandler) DeleteDataset(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+
+	if err := h.evalService.DeleteDataset(ctx, id); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"dataset_id": id,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// CreateRun godoc
+// @Summary      发起评测运行
+// @Description  用指定的检索配置在知识库上运行评测数据集，异步执行；rag 模式还会生成回答并由评审模型打分
+// @Tags         检索评测
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.EvalRunRequest    true  "评测运行"
+// @Success      200      {object}  map[string]interface{}  "评测运行"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Failure      404      {object}  errors.AppError         "数据集或知识库不存在"
+// @Security     Bearer
+// @Router       /eval-runs [post]
+func (h *RetrievalEvalHandler) CreateRun(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req types.EvalRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to bind evaluation run payload", err)
+		c.Error(apperrors.NewBadRequestError("请求参数不合法").WithDetails(err.Error()))
+		return
+	}
+
+	run, err := h.evalService.CreateRun(ctx, &req)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"dataset_id":        secutils.SanitizeForLog(req.DatasetID),
+			"knowledge_base_id": secutils.SanitizeForLog(req.KnowledgeBaseID),
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    run,
+	})
+}
+
+// ListRuns godoc
+// @Summary      获取评测运行列表
+// @Description  获取当前租户的评测运行，按创建时间倒序
+// @Tags         检索评测
+// @Produce      json
+// @Param        dataset_id  query     string                  false  "只列出该数据集的运行"
+// @Success      200         {object}  map[string]interface{}  "评测运行列表"
+// @Security     Bearer
+// @Router       /eval-runs [get]
+func (h *RetrievalEvalHandler) ListRuns(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	runs, err := h.evalService.ListRuns(ctx, c.Query("dataset_id"))
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    runs,
+	})
+}
+
+// GetRun godoc
+// @Summary      获取评测运行
+// @Description  获取评测运行的状态、进度与汇总指标
+// @Tags         检索评测
+// @Produce      json
+// @Param        id   path      string                  true  "运行 ID"
+// @Success      200  {object}  map[string]interface{}  "评测运行"
+// @Failure      404  {object}  errors.AppError         "评测运行不存在"
+// @Security     Bearer
+// @Router       /eval-runs/{id} [get]
+func (h *RetrievalEvalHandler) GetRun(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+
+	run, err := h.evalService.GetRun(ctx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"run_id": id,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    run,
+	})
+}
+
+// ListRunResults godoc
+// @Summary      获取评测运行的逐题结果
+// @Description  获取每个问题的检索结果、指标、回答与评审理由
+// @Tags         检索评测
+// @Produce      json
+// @Param        id   path      string                  true  "运行 ID"
+// @Success      200  {object}  map[string]interface{}  "逐题结果"
+// @Failure      404  {object}  errors.AppError         "评测运行不存在"
+// @Security     Bearer
+// @Router       /eval-runs/{id}/results [get]
+func (h *RetrievalEvalHandler) ListRunResults(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+
+	results, err := h.evalService.ListRunResults(ctx, id)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"run_id": id,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    results,
+	})
+}
+
+// DeleteRun godoc
+// @Summary      删除评测运行
+// @Description  删除评测运行及其逐题结果
+// @Tags         检索评测
+// @Produce      json
+// @Param        id   path      string                  true  "运行 ID"
+// @Success      200  {object}  map[string]interface{}  "删除成功"
+// @Failure      404  {object}  errors.AppError         "评测运行不存在"
+// @Security     Bearer
+// @Router       /eval-runs/{id} [delete]
+func (h *RetrievalEvalHandler) DeleteRun(c *gin.Context) {
+	ctx := c.Request.Context()
+	id := secutils.SanitizeForLog(c.Param("id"))
+
+	if err := h.evalService.DeleteRun(ctx, id); err != nil {
+		logger.ErrorWithFields(ctx, err, map[string]interface{}{
+			"run_id": id,
+		})
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+	})
+}
+
+// CompareRuns godoc
+// @Summary      对比评测运行
+// @Description  以第一个运行为基线，返回各运行的指标差值；同一数据集的运行还会返回逐题指标对比
+// @Tags         检索评测
+// @Produce      json
+// @Param        run_ids  query     string                  true  "逗号分隔的运行 ID，第一个为基线，2 到 10 个"
+// @Success      200      {object}  map[string]interface{}  "对比结果"
+// @Failure      400      {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Router       /eval-runs/compare [get]
+func (h *RetrievalEvalHandler) CompareRuns(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	ids := strings.Split(c.Query("run_ids"), ",")
+	comparison, err := h.evalService.CompareRuns(ctx, ids)
+	if err != nil {
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    comparison,
+	})
+}
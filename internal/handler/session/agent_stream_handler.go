@@ -103,6 +103,10 @@ func (h *AgentStreamHandler) Subscribe() {
 	h.eventBus.On(event.EventAgentToolCall, h.handleToolCall)
 	h.eventBus.On(event.EventAgentToolResult, h.handleToolResult)
 	h.eventBus.On(event.EventAgentReferences, h.handleReferences)
+	h.eventBus.On(event.EventAgentCitations, h.handleCitations)
+	h.eventBus.On(event.EventAgentVerification, h.handleVerification)
+	h.eventBus.On(event.EventAgentFollowUps, h.handleFollowUps)
+	h.eventBus.On(event.EventAgentDebugTrace, h.handleDebugTrace)
 	h.eventBus.On(event.EventAgentFinalAnswer, h.handleFinalAnswer)
 	h.eventBus.On(event.EventAgentReflection, h.handleReflection)
 	h.eventBus.On(event.EventError, h.handleError)
@@ -436,6 +440,114 @@ func (h *AgentStreamHandler) handleReferences(ctx context.Context, evt event.Eve
 	return nil
 }
 
+// handleCitations handles the sources cited inline in the answer
+func (h *AgentStreamHandler) handleCitations(ctx context.Context, evt event.Event) error {
+	data, ok := evt.Data.(event.AgentCitationsData)
+	if !ok {
+		return nil
+	}
+
+	h.mu.Lock()
+	h.assistantMessage.Citations = data.Citations
+	h.mu.Unlock()
+
+	if err := h.streamManager.AppendEvent(h.ctx, h.sessionID, h.assistantMessageID, interfaces.StreamEvent{
+		ID:        evt.ID,
+		Type:      types.ResponseTypeCitations,
+		Content:   "",
+		Done:      false,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"citations": data.Citations,
+		},
+	}); err != nil {
+		logger.GetLogger(h.ctx).Error("Append citations event to stream failed", "error", err)
+	}
+
+	return nil
+}
+
+// handleVerification handles the verification of the answer against the
+// retrieved context. A regenerated answer replaces everything streamed
+// before it.
+func (h *AgentStreamHandler) handleVerification(ctx context.Context, evt event.Event) error {
+	data, ok := evt.Data.(event.AgentVerificationData)
+	if !ok || data.Verification == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	h.assistantMessage.Verification = data.Verification
+	if data.Verification.Regenerated {
+		for _, seg := range h.answerSegments {
+			seg.superseded = true
+		}
+		h.finalAnswer = h.composeFinalAnswer()
+	}
+	h.mu.Unlock()
+
+	if err := h.streamManager.AppendEvent(h.ctx, h.sessionID, h.assistantMessageID, interfaces.StreamEvent{
+		ID:        evt.ID,
+		Type:      types.ResponseTypeVerification,
+		Content:   "",
+		Done:      false,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"verification": data.Verification,
+		},
+	}); err != nil {
+		logger.GetLogger(h.ctx).Error("Append verification event to stream failed", "error", err)
+	}
+
+	return nil
+}
+
+// handleFollowUps handles the follow-up questions suggested after the answer
+func (h *AgentStreamHandler) handleFollowUps(ctx context.Context, evt event.Event) error {
+	data, ok := evt.Data.(event.AgentFollowUpsData)
+	if !ok || len(data.Questions) == 0 {
+		return nil
+	}
+
+	if err := h.streamManager.AppendEvent(h.ctx, h.sessionID, h.assistantMessageID, interfaces.StreamEvent{
+		ID:        evt.ID,
+		Type:      types.ResponseTypeFollowUps,
+		Content:   "",
+		Done:      false,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"questions": data.Questions,
+		},
+	}); err != nil {
+		logger.GetLogger(h.ctx).Error("Append follow-ups event to stream failed", "error", err)
+	}
+
+	return nil
+}
+
+// handleDebugTrace handles the stage trace of a debug-traced run
+func (h *AgentStreamHandler) handleDebugTrace(ctx context.Context, evt event.Event) error {
+	data, ok := evt.Data.(event.AgentDebugTraceData)
+	if !ok || data.Trace == nil {
+		return nil
+	}
+
+	if err := h.streamManager.AppendEvent(h.ctx, h.sessionID, h.assistantMessageID, interfaces.StreamEvent{
+		ID:        evt.ID,
+		Type:      types.ResponseTypeDebugTrace,
+		Content:   "",
+		Done:      false,
+		Timestamp: time.Now(),
+		Data: map[string]interface{}{
+			"trace": data.Trace,
+		},
+	}); err != nil {
+		logger.GetLogger(h.ctx).Error("Append debug trace event to stream failed", "error", err)
+	}
+
+	return nil
+}
+
 // handleFinalAnswer handles final answer events
 func (h *AgentStreamHandler) handleFinalAnswer(ctx context.Context, evt event.Event) error {
 	data, ok := evt.Data.(event.AgentFinalAnswerData)
@@ -495,6 +607,12 @@ func (h *AgentStreamHandler) handleFinalAnswer(ctx context.Context, evt event.Ev
 	if data.IsFallback {
 		metadata["is_fallback"] = true
 	}
+	if data.Done && data.Metadata != nil {
+		metadata["model_id"] = data.Metadata.ModelID
+		if data.Metadata.FallbackFrom != "" {
+			metadata["fallback_from"] = data.Metadata.FallbackFrom
+		}
+	}
 	h.mu.Unlock()
 
 	// Append this chunk to stream (frontend will accumulate by event ID)
@@ -616,7 +734,13 @@ func (h *AgentStreamHandler) handleComplete(ctx context.Context, evt event.Event
 			h.assistantMessage.KnowledgeReferences = knowledgeRefs
 		}
 
-		h.assistantMessage.Content += data.FinalAnswer
+		// A stopped run ends without a final answer; keep the part of it
+		// streamed before the stop.
+		finalAnswer := data.FinalAnswer
+		if finalAnswer == "" && h.ctx.Err() != nil {
+			finalAnswer = h.finalAnswer
+		}
+		h.assistantMessage.Content += finalAnswer
 
 		// Update agent steps if provided
 		if data.AgentSteps != nil {
@@ -2,8 +2,10 @@ package session
 
 import (
 	stderrors "errors"
+	"fmt"
 	"net/http"
 
+	"github.com/Tencent/WeKnora/internal/application/service/memory"
 	"github.com/Tencent/WeKnora/internal/config"
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/infrastructure/docparser"
@@ -28,6 +30,10 @@ type Handler struct {
 	fileService          interfaces.FileService          // Service for file storage (image uploads)
 	modelService         interfaces.ModelService         // Service for model management (VLM access)
 	userService          interfaces.UserService          // Service for resolving per-user preferences (e.g. enable_memory default)
+	taskEnqueuer         interfaces.TaskEnqueuer         // Queue for memory extraction when a session ends
+	tokenUsageService    interfaces.TokenUsageService    // Service for checking the tenant token budget before answering
+	experimentService    interfaces.ExperimentService    // Service for sampling completed answers into shadow experiments
+	analyticsService     interfaces.AnalyticsService     // Service for logging answered queries for analytics
 	attachmentProcessor  *AttachmentProcessor            // Processor for file attachments
 }
 
@@ -47,6 +53,10 @@ func NewHandler(
 	userService interfaces.UserService,
 	documentReader interfaces.DocumentReader,
 	imageResolver *docparser.ImageResolver,
+	taskEnqueuer interfaces.TaskEnqueuer,
+	tokenUsageService interfaces.TokenUsageService,
+	experimentService interfaces.ExperimentService,
+	analyticsService interfaces.AnalyticsService,
 ) *Handler {
 	return &Handler{
 		sessionService:       sessionService,
@@ -61,6 +71,10 @@ func NewHandler(
 		fileService:          fileService,
 		modelService:         modelService,
 		userService:          userService,
+		taskEnqueuer:         taskEnqueuer,
+		tokenUsageService:    tokenUsageService,
+		experimentService:    experimentService,
+		analyticsService:     analyticsService,
 		attachmentProcessor: NewAttachmentProcessor(
 			fileService,
 			documentReader,
@@ -109,11 +123,27 @@ func (h *Handler) CreateSession(c *gin.Context) {
 		tenantID,
 	)
 
+	if err := request.MemoryPolicy.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if err := request.RerankConfig.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if err := request.PipelineConfig.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
 	// Create session object with base properties
 	createdSession := &types.Session{
-		TenantID:    tenantID.(uint64),
-		Title:       request.Title,
-		Description: request.Description,
+		TenantID:       tenantID.(uint64),
+		Title:          request.Title,
+		Description:    request.Description,
+		MemoryPolicy:   request.MemoryPolicy,
+		RerankConfig:   request.RerankConfig,
+		PipelineConfig: request.PipelineConfig,
 	}
 	// Attach the calling user as the session owner when available.
 	// API-key / legacy callers without a user id fall back to tenant-level visibility.
@@ -276,6 +306,18 @@ func (h *Handler) UpdateSession(c *gin.Context) {
 		c.Error(errors.NewBadRequestError(err.Error()))
 		return
 	}
+	if err := session.MemoryPolicy.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if err := session.RerankConfig.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+	if err := session.PipelineConfig.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
 
 	session.ID = id
 	session.TenantID = tenantID.(uint64)
@@ -537,3 +579,64 @@ func (h *Handler) setSessionPinned(c *gin.Context, pinned bool) {
 		"is_pinned": pinned,
 	})
 }
+
+// EndSession godoc
+// @Summary      结束会话
+// @Description  结束会话。会话的记忆策略为 session_end 时，将整个会话作为一段记忆写入用户的长期记忆
+// @Tags         会话
+// @Produce      json
+// @Param        session_id  path      string  true  "会话ID"
+// @Success      200         {object}  map[string]interface{}  "是否已提交记忆抽取"
+// @Failure      404         {object}  errors.AppError         "会话不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sessions/{session_id}/end [post]
+func (h *Handler) EndSession(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id := secutils.SanitizeForLog(c.Param("session_id"))
+	if id == "" {
+		logger.Error(ctx, "Session ID is empty")
+		c.Error(errors.NewBadRequestError(errors.ErrInvalidSessionID.Error()))
+		return
+	}
+
+	session, err := h.sessionService.GetSession(ctx, id)
+	if err != nil {
+		if stderrors.Is(err, errors.ErrSessionNotFound) {
+			logger.Warnf(ctx, "Session not found, ID: %s", id)
+			c.Error(errors.NewNotFoundError(err.Error()))
+			return
+		}
+		logger.ErrorWithFields(ctx, err, nil)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	// Only sessions that remember on close have anything to store; the
+	// other policies have stored their turns as they went.
+	queued := false
+	userID, _ := types.UserIDFromContext(ctx)
+	if session.MemoryPolicy.EffectiveTrigger() == types.MemoryTriggerSessionEnd &&
+		userID != "" && h.resolveEnableMemory(ctx, nil) {
+		payload := &types.MemoryExtractPayload{
+			TenantID:  session.TenantID,
+			UserID:    userID,
+			SessionID: session.ID,
+			// Ending the session again without new turns is a no-op.
+			TurnID:  fmt.Sprintf("end-%d", session.UpdatedAt.UnixNano()),
+			Trigger: types.MemoryTriggerSessionEnd,
+		}
+		if err := memory.EnqueueExtraction(ctx, h.taskEnqueuer, payload); err != nil {
+			logger.ErrorWithFields(ctx, err, map[string]interface{}{"session_id": id})
+			c.Error(errors.NewInternalServerError(err.Error()))
+			return
+		}
+		queued = true
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"memory_queued": queued},
+	})
+}
@@ -2,8 +2,10 @@ package session
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/event"
@@ -188,6 +190,26 @@ func buildStreamResponse(evt interfaces.StreamEvent, requestID string) *types.St
 		}
 	}
 
+	if evt.Type == types.ResponseTypeCitations {
+		response.Citations = citationsFromEventData(evt.Data["citations"])
+		return response
+	}
+
+	if evt.Type == types.ResponseTypeVerification {
+		response.Verification = verificationFromEventData(evt.Data["verification"])
+		return response
+	}
+
+	if evt.Type == types.ResponseTypeFollowUps {
+		response.FollowUpQuestions = followUpsFromEventData(evt.Data["questions"])
+		return response
+	}
+
+	if evt.Type == types.ResponseTypeDebugTrace {
+		response.DebugTrace = debugTraceFromEventData(evt.Data["trace"])
+		return response
+	}
+
 	// Special handling for references event
 	if evt.Type == types.ResponseTypeReferences {
 		refsData := evt.Data["references"]
@@ -231,6 +253,88 @@ func buildStreamResponse(evt interfaces.StreamEvent, requestID string) *types.St
 	return response
 }
 
+// citationsFromEventData reads the citations of a stream event, which are
+// generic JSON values when the event was read back from Redis.
+func citationsFromEventData(data interface{}) types.Citations {
+	switch v := data.(type) {
+	case nil:
+		return nil
+	case types.Citations:
+		return v
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		var citations types.Citations
+		if err := json.Unmarshal(raw, &citations); err != nil {
+			return nil
+		}
+		return citations
+	}
+}
+
+// verificationFromEventData reads the answer verification of a stream event,
+// which is a generic JSON value when the event was read back from Redis.
+func verificationFromEventData(data interface{}) *types.AnswerVerification {
+	switch v := data.(type) {
+	case nil:
+		return nil
+	case *types.AnswerVerification:
+		return v
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		var verification types.AnswerVerification
+		if err := json.Unmarshal(raw, &verification); err != nil {
+			return nil
+		}
+		return &verification
+	}
+}
+
+// followUpsFromEventData reads the follow-up questions of a stream event,
+// which are a generic JSON array when the event was read back from Redis.
+func followUpsFromEventData(data interface{}) []string {
+	switch v := data.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		questions := make([]string, 0, len(v))
+		for _, q := range v {
+			if s, ok := q.(string); ok {
+				questions = append(questions, s)
+			}
+		}
+		return questions
+	default:
+		return nil
+	}
+}
+
+// debugTraceFromEventData reads the pipeline trace of a stream event, which
+// is a generic JSON value when the event was read back from Redis.
+func debugTraceFromEventData(data interface{}) *types.PipelineTrace {
+	switch v := data.(type) {
+	case nil:
+		return nil
+	case *types.PipelineTrace:
+		return v
+	default:
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return nil
+		}
+		var trace types.PipelineTrace
+		if err := json.Unmarshal(raw, &trace); err != nil {
+			return nil
+		}
+		return &trace
+	}
+}
+
 // sendCompletionEvent sends a final completion event to the client
 // NOTE: This is now a no-op because:
 //  1. The 'complete' event from handleComplete already signals stream completion
@@ -304,17 +408,37 @@ func (h *Handler) setupStopEventHandler(
 	assistantMessage *types.Message,
 	cancel context.CancelFunc,
 ) {
+	// Both the SSE loop and the stop watcher forward the stop; handle it once.
+	var stopOnce sync.Once
 	eventBus.On(event.EventStop, func(ctx context.Context, evt event.Event) error {
-		logger.Infof(ctx, "Received stop event, cancelling async operations for session: %s", sessionID)
-		cancel()
-		// Preserve whatever has been streamed so far; do not overwrite Content.
-		// Use session's tenant for message update (ctx may have effectiveTenantID when using shared agent).
-		// Use WithoutCancel so the GORM UPDATE survives the upcoming ctx.Done triggered by cancel()/client disconnect.
-		updateCtx := context.WithValue(
-			context.WithoutCancel(ctx),
-			types.TenantIDContextKey, sessionTenantID,
-		)
-		h.completeAssistantMessage(updateCtx, assistantMessage, "") // empty query: stopped conversations are not indexed
+		stopOnce.Do(func() {
+			logger.Infof(ctx, "Received stop event, cancelling async operations for session: %s", sessionID)
+			cancel()
+			// Preserve whatever has been streamed so far; do not overwrite Content.
+			// Use session's tenant for message update (ctx may have effectiveTenantID when using shared agent).
+			// Use WithoutCancel so the GORM UPDATE survives the upcoming ctx.Done triggered by cancel()/client disconnect.
+			updateCtx := context.WithValue(
+				context.WithoutCancel(ctx),
+				types.TenantIDContextKey, sessionTenantID,
+			)
+			h.completeAssistantMessage(updateCtx, assistantMessage, "") // empty query: stopped conversations are not indexed
+
+			// Let the pipeline's listeners drop work that only applies to a
+			// finished answer, such as storing the turn in memory.
+			reason := "user_requested"
+			if data, ok := evt.Data.(event.StopData); ok && data.Reason != "" {
+				reason = data.Reason
+			}
+			eventBus.Emit(updateCtx, event.Event{
+				Type:      event.EventCancelled,
+				SessionID: sessionID,
+				Data: event.CancelledData{
+					SessionID: sessionID,
+					MessageID: assistantMessage.ID,
+					Reason:    reason,
+				},
+			})
+		})
 		return nil
 	})
 }
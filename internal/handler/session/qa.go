@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	agenttools "github.com/Tencent/WeKnora/internal/agent/tools"
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/event"
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -38,6 +39,8 @@ type qaRequestContext struct {
 	summaryModelID    string
 	webSearchEnabled  bool
 	enableMemory      bool // Whether memory feature is enabled
+	remember          bool // Store this turn in memory whatever the session's memory policy says
+	debug             bool // Stream a per-stage pipeline trace before the answer
 	mentionedItems    types.MentionedItems
 	effectiveTenantID uint64                   // when using shared agent, tenant ID for model/KB/MCP resolution; 0 = use context tenant
 	images            []ImageAttachment        // Uploaded images with analysis text
@@ -71,6 +74,8 @@ func (rc *qaRequestContext) buildQARequest() *types.QARequest {
 		UserMessageID:      rc.userMessageID,
 		WebSearchEnabled:   rc.webSearchEnabled,
 		EnableMemory:       rc.enableMemory,
+		Remember:           rc.remember,
+		Debug:              rc.debug,
 		Attachments:        rc.attachments,
 	}
 }
@@ -103,6 +108,14 @@ func (h *Handler) parseQARequest(c *gin.Context, logPrefix string) (*qaRequestCo
 		return nil, nil, errors.NewBadRequestError("Query content cannot be empty")
 	}
 
+	// Debug traces expose prompts and raw retrieval results; only tenant
+	// admins (and API keys, which act as admins) may ask for them.
+	if request.Debug && !types.TenantRoleFromContext(ctx).HasPermission(types.TenantRoleAdmin) &&
+		!types.IsSystemAdminFromContext(ctx) {
+		logger.Warnf(ctx, "[%s] Debug trace requested without admin role", logPrefix)
+		return nil, nil, errors.NewForbiddenError("Debug trace requires the tenant admin role")
+	}
+
 	// SSRF protection: strip client-supplied URL/Caption fields from image attachments.
 	// The URL field must only be populated server-side by saveImageAttachments; an
 	// attacker could inject internal network URLs to trigger SSRF via the LLM provider.
@@ -124,6 +137,14 @@ func (h *Handler) parseQARequest(c *gin.Context, logPrefix string) (*qaRequestCo
 		return nil, nil, errors.NewNotFoundError("Session not found")
 	}
 
+	// Attribute the token usage of the answer to the session, and refuse to
+	// answer once the tenant has used up its token budget.
+	ctx = context.WithValue(ctx, types.SessionIDContextKey, sessionID)
+	if err := h.tokenUsageService.CheckBudget(ctx); err != nil {
+		logger.Warnf(ctx, "[%s] Rejected: %v", logPrefix, err)
+		return nil, nil, errors.NewTenantTokenBudgetExceededError()
+	}
+
 	// Get custom agent if agent_id is provided. Backend resolves shared agent from share relation (no client-provided tenant).
 	customAgent, effectiveTenantID := h.resolveAgent(ctx, c, request.AgentID)
 
@@ -282,6 +303,8 @@ func (h *Handler) parseQARequest(c *gin.Context, logPrefix string) (*qaRequestCo
 		summaryModelID:    secutils.SanitizeForLog(request.SummaryModelID),
 		webSearchEnabled:  request.WebSearchEnabled,
 		enableMemory:      enableMemory,
+		remember:          request.Remember,
+		debug:             request.Debug,
 		mentionedItems:    convertMentionedItems(request.MentionedItems),
 		effectiveTenantID: effectiveTenantID,
 		images:            request.Images,
@@ -533,6 +556,11 @@ func (h *Handler) SearchKnowledge(c *gin.Context) {
 		secutils.SanitizeForLog(request.Query),
 	)
 
+	var trace *types.RetrievalTrace
+	if request.Debug {
+		ctx, trace = types.WithRetrievalTrace(ctx)
+	}
+
 	// Directly call knowledge retrieval service without LLM summarization
 	searchResults, err := h.sessionService.SearchKnowledge(ctx, knowledgeBaseIDs, request.KnowledgeIDs, request.Query)
 	if err != nil {
@@ -542,10 +570,18 @@ func (h *Handler) SearchKnowledge(c *gin.Context) {
 	}
 
 	logger.Infof(ctx, "Knowledge search completed, found %d results", len(searchResults))
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"success": true,
 		"data":    searchResults,
-	})
+	}
+	if trace != nil {
+		exclusions := trace.Exclusions()
+		if exclusions == nil {
+			exclusions = []types.ChunkExclusion{}
+		}
+		resp["debug"] = gin.H{"exclusions": exclusions}
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // KnowledgeQA godoc
@@ -712,6 +748,16 @@ func (h *Handler) executeQA(reqCtx *qaRequestContext, mode qaMode, generateTitle
 			return nil
 		})
 
+		// A regenerated answer (answer verification) replaces the answer
+		// streamed before it.
+		streamCtx.eventBus.On(event.EventAgentVerification, func(ctx context.Context, evt event.Event) error {
+			data, ok := evt.Data.(event.AgentVerificationData)
+			if ok && data.Verification != nil && data.Verification.Regenerated {
+				streamCtx.assistantMessage.Content = ""
+			}
+			return nil
+		})
+
 		streamCtx.eventBus.On(event.EventAgentFinalAnswer, func(ctx context.Context, evt event.Event) error {
 			data, ok := evt.Data.(event.AgentFinalAnswerData)
 			if !ok {
@@ -721,6 +767,9 @@ func (h *Handler) executeQA(reqCtx *qaRequestContext, mode qaMode, generateTitle
 			if data.IsFallback {
 				streamCtx.assistantMessage.IsFallback = true
 			}
+			if data.Metadata != nil {
+				streamCtx.assistantMessage.Metadata = data.Metadata
+			}
 			if data.Done {
 				if completionHandled {
 					return nil
@@ -730,6 +779,8 @@ func (h *Handler) executeQA(reqCtx *qaRequestContext, mode qaMode, generateTitle
 				logger.Infof(streamCtx.asyncCtx, "Knowledge QA service completed for session: %s", sessionID)
 				updateCtx := context.WithValue(streamCtx.asyncCtx, types.TenantIDContextKey, reqCtx.session.TenantID)
 				h.completeAssistantMessage(updateCtx, streamCtx.assistantMessage, reqCtx.query)
+				h.observeExperiments(updateCtx, reqCtx, streamCtx.assistantMessage)
+				h.recordQuery(updateCtx, reqCtx, streamCtx.assistantMessage, mode)
 				streamCtx.eventBus.Emit(streamCtx.asyncCtx, event.Event{
 					Type:      event.EventAgentComplete,
 					SessionID: sessionID,
@@ -766,6 +817,10 @@ func (h *Handler) executeQA(reqCtx *qaRequestContext, mode qaMode, generateTitle
 					types.TenantIDContextKey, reqCtx.session.TenantID,
 				)
 				h.completeAssistantMessage(updateCtx, streamCtx.assistantMessage, reqCtx.query)
+				// A stopped agent has no complete answer to log
+				if streamCtx.asyncCtx.Err() == nil {
+					h.recordQuery(updateCtx, reqCtx, streamCtx.assistantMessage, mode)
+				}
 				logger.Infof(streamCtx.asyncCtx, "Agent QA service completed for session: %s", sessionID)
 			}
 		}()
@@ -828,21 +883,7 @@ func (h *Handler) runVLMAnalysisIfNeeded(streamCtx *sseStreamContext, reqCtx *qa
 
 	// In normal mode, only run VLM for pure-chat path
 	if mode == qaModeNormal {
-		hasRequestKBs := len(reqCtx.knowledgeBaseIDs) > 0 || len(reqCtx.knowledgeIDs) > 0
-		agentWillResolveKBs := false
-		if !hasRequestKBs && reqCtx.customAgent != nil && !reqCtx.customAgent.Config.RetrieveKBOnlyWhenMentioned {
-			switch reqCtx.customAgent.Config.KBSelectionMode {
-			case "all":
-				agentWillResolveKBs = true
-			case "selected", "":
-				agentWillResolveKBs = len(reqCtx.customAgent.Config.KnowledgeBases) > 0
-			case "none":
-				agentWillResolveKBs = false
-			default:
-				agentWillResolveKBs = len(reqCtx.customAgent.Config.KnowledgeBases) > 0
-			}
-		}
-		if hasRequestKBs || agentWillResolveKBs || reqCtx.webSearchEnabled {
+		if reqCtx.searchesKnowledge() || reqCtx.webSearchEnabled {
 			return // VLM will be handled by the pipeline rewrite step
 		}
 	}
@@ -935,6 +976,88 @@ func appendQuickAnswerReasoning(msg *types.Message, content string) {
 	msg.AgentSteps[0].ReasoningContent += content
 }
 
+// searchesKnowledge reports whether a normal-mode request searches
+// knowledge: it names knowledge bases or files, or its agent resolves
+// knowledge bases of its own.
+func (reqCtx *qaRequestContext) searchesKnowledge() bool {
+	if len(reqCtx.knowledgeBaseIDs) > 0 || len(reqCtx.knowledgeIDs) > 0 {
+		return true
+	}
+	if reqCtx.customAgent == nil || reqCtx.customAgent.Config.RetrieveKBOnlyWhenMentioned {
+		return false
+	}
+	switch reqCtx.customAgent.Config.KBSelectionMode {
+	case "all":
+		return true
+	case "none":
+		return false
+	default:
+		return len(reqCtx.customAgent.Config.KnowledgeBases) > 0
+	}
+}
+
+// agentSearchedKnowledge reports whether an agent called a knowledge search tool
+func agentSearchedKnowledge(steps types.AgentSteps) bool {
+	for _, step := range steps {
+		for _, call := range step.ToolCalls {
+			if call.Name == agenttools.ToolKnowledgeSearch || call.Name == agenttools.ToolGrepChunks {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recordQuery logs a completed answer for the query analytics. Like
+// observeExperiments it runs in the background.
+func (h *Handler) recordQuery(
+	ctx context.Context, reqCtx *qaRequestContext, assistantMessage *types.Message, mode qaMode,
+) {
+	if h.analyticsService == nil || assistantMessage.Content == "" {
+		return
+	}
+	record := &types.QueryRecord{
+		SessionID:        reqCtx.sessionID,
+		MessageID:        assistantMessage.ID,
+		Channel:          assistantMessage.Channel,
+		Mode:             types.QueryLogModeQuick,
+		Query:            reqCtx.query,
+		KnowledgeBaseIDs: reqCtx.knowledgeBaseIDs,
+		KnowledgeIDs:     reqCtx.knowledgeIDs,
+		References:       assistantMessage.KnowledgeReferences,
+		Citations:        assistantMessage.Citations,
+		ReceivedAt:       reqCtx.receivedAt,
+		AnsweredAt:       time.Now(),
+	}
+	if mode == qaModeAgent {
+		// An agent decides on its own whether to search
+		record.Mode = types.QueryLogModeAgent
+		record.Searched = agentSearchedKnowledge(assistantMessage.AgentSteps)
+	} else {
+		record.Searched = reqCtx.searchesKnowledge()
+	}
+	go h.analyticsService.RecordQuery(logger.CloneContext(context.WithoutCancel(ctx)), record)
+}
+
+// observeExperiments offers a completed quick answer to the tenant's shadow
+// experiments. It runs in the background so sampling never delays the
+// response; the shadow answers themselves run in async tasks.
+func (h *Handler) observeExperiments(ctx context.Context, reqCtx *qaRequestContext, assistantMessage *types.Message) {
+	if h.experimentService == nil || assistantMessage.Content == "" {
+		return
+	}
+	observation := &types.ExperimentObservation{
+		SessionID:        reqCtx.sessionID,
+		MessageID:        assistantMessage.ID,
+		Question:         reqCtx.query,
+		ChatModelID:      reqCtx.summaryModelID,
+		KnowledgeBaseIDs: reqCtx.knowledgeBaseIDs,
+		KnowledgeIDs:     reqCtx.knowledgeIDs,
+		Sources:          assistantMessage.KnowledgeReferences,
+	}
+	go h.experimentService.ObserveAnswer(logger.CloneContext(context.WithoutCancel(ctx)), observation)
+}
+
 // completeAssistantMessage marks an assistant message as complete, updates it,
 // and asynchronously indexes the Q&A pair into the chat history knowledge base.
 func (h *Handler) completeAssistantMessage(ctx context.Context, assistantMessage *types.Message, userQuery string) {
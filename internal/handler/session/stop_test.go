@@ -0,0 +1,104 @@
+package session
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/Tencent/WeKnora/internal/event"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+)
+
+// stubStopMessageService records the assistant message updates made when a
+// stop is handled. Other methods panic through the nil embedded interface.
+type stubStopMessageService struct {
+	interfaces.MessageService
+	mu       sync.Mutex
+	updates  int
+	tenantID uint64
+}
+
+func (s *stubStopMessageService) UpdateMessage(ctx context.Context, _ *types.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.updates++
+	s.tenantID, _ = types.TenantIDFromContext(ctx)
+	return nil
+}
+
+func (s *stubStopMessageService) IndexMessageToKB(context.Context, string, string, string, string) {}
+
+// stubStreamManager accepts every stream event.
+type stubStreamManager struct {
+	interfaces.StreamManager
+}
+
+func (stubStreamManager) AppendEvent(context.Context, string, string, interfaces.StreamEvent) error {
+	return nil
+}
+
+func TestSetupStopEventHandlerCancelsOnce(t *testing.T) {
+	messages := &stubStopMessageService{}
+	h := &Handler{messageService: messages}
+	bus := event.NewEventBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var cancelled []event.CancelledData
+	bus.On(event.EventCancelled, func(_ context.Context, evt event.Event) error {
+		cancelled = append(cancelled, evt.Data.(event.CancelledData))
+		return nil
+	})
+	assistant := &types.Message{ID: "msg-1", SessionID: "session-1", Content: "partial"}
+	h.setupStopEventHandler(bus, "session-1", 7, assistant, cancel)
+
+	// The SSE loop and the stop watcher both forward the same stop.
+	for range 2 {
+		require.NoError(t, bus.Emit(ctx, event.Event{
+			Type:      event.EventStop,
+			SessionID: "session-1",
+			Data:      event.StopData{SessionID: "session-1", MessageID: "msg-1", Reason: "timeout"},
+		}))
+	}
+
+	require.Len(t, cancelled, 1, "a double stop emits one cancelled event")
+	assert.Equal(t, event.CancelledData{SessionID: "session-1", MessageID: "msg-1", Reason: "timeout"}, cancelled[0])
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+	assert.Equal(t, 1, messages.updates)
+	assert.Equal(t, uint64(7), messages.tenantID, "the message is saved under the session's tenant")
+	assert.True(t, assistant.IsCompleted)
+	assert.Equal(t, "partial", assistant.Content)
+}
+
+func TestAgentStreamHandlerKeepsPartialAnswerOnStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus := event.NewEventBus()
+	assistant := &types.Message{ID: "msg-1"}
+	handler := NewAgentStreamHandler(ctx, "session-1", "msg-1", "req-1", time.Time{},
+		assistant, stubStreamManager{}, bus)
+	handler.Subscribe()
+
+	for _, chunk := range []string{"Annual leave is ", "15 days"} {
+		require.NoError(t, bus.Emit(ctx, event.Event{
+			ID:   "answer-1",
+			Type: event.EventAgentFinalAnswer,
+			Data: event.AgentFinalAnswerData{Content: chunk},
+		}))
+	}
+
+	// A stopped run completes without a final answer.
+	cancel()
+	require.NoError(t, bus.Emit(context.Background(), event.Event{
+		Type: event.EventAgentComplete,
+		Data: event.AgentCompleteData{SessionID: "session-1", MessageID: "msg-1"},
+	}))
+
+	assert.Equal(t, "Annual leave is 15 days", assistant.Content)
+	assert.True(t, assistant.IsCompleted)
+}
@@ -12,6 +12,15 @@ type CreateSessionRequest struct {
 	Title string `json:"title"`
 	// Description for the session (optional)
 	Description string `json:"description"`
+	// MemoryPolicy controls when the session's turns are stored in memory
+	// (optional, every turn by default)
+	MemoryPolicy *types.MemoryPolicy `json:"memory_policy"`
+	// RerankConfig turns reranking on or off and sets its top-N for the
+	// session (optional, the agent's settings by default)
+	RerankConfig *types.SessionRerankConfig `json:"rerank_config"`
+	// PipelineConfig sets the knowledge QA pipeline stages for the session
+	// (optional, the agent's and tenant's settings by default)
+	PipelineConfig *types.PipelineConfig `json:"pipeline_config"`
 }
 
 // GenerateTitleRequest defines the request structure for generating a session title
@@ -69,6 +78,12 @@ type CreateKnowledgeQARequest struct {
 	Images            []ImageAttachment  `json:"images"`                       // Attached images for multimodal chat
 	AttachmentUploads []AttachmentUpload `json:"attachment_uploads,omitempty"` // Attached files (documents, audio, etc.)
 	Channel           string             `json:"channel"`                      // Source channel: "web", "api", "im", etc.
+	// Remember stores this turn in memory whatever the session's memory
+	// policy says; it is the only trigger of the "explicit" policy.
+	Remember bool `json:"remember,omitempty"`
+	// Debug streams a per-stage trace of the knowledge QA pipeline before
+	// the answer; it needs the tenant admin role
+	Debug bool `json:"debug,omitempty"`
 }
 
 // AttachmentUpload represents a file attachment upload from the client
@@ -84,6 +99,9 @@ type SearchKnowledgeRequest struct {
 	KnowledgeBaseID  string   `json:"knowledge_base_id"`                     // Single knowledge base ID (for backward compatibility)
 	KnowledgeBaseIDs []string `json:"knowledge_base_ids"`                    // IDs of knowledge bases to search (multi-KB support)
 	KnowledgeIDs     []string `json:"knowledge_ids"`                         // IDs of specific knowledge (files) to search
+	// Debug adds a "debug" object to the response listing every candidate
+	// dropped along the way and why (threshold, dedup, top-k, disabled, ACL).
+	Debug bool `json:"debug"`
 }
 
 // StopSessionRequest represents the stop session request
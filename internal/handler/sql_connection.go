@@ -0,0 +1,343 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/handler/dto"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// SQLConnectionHandler handles HTTP requests for SQL connection CRUD
+type SQLConnectionHandler struct {
+	service interfaces.SQLConnectionService
+}
+
+// NewSQLConnectionHandler creates a new handler
+func NewSQLConnectionHandler(service interfaces.SQLConnectionService) *SQLConnectionHandler {
+	return &SQLConnectionHandler{service: service}
+}
+
+// SQLConnectionRequest defines the request body for creating, updating and
+// testing a connection. On update an empty password keeps the stored one.
+type SQLConnectionRequest struct {
+	Name          string                        `json:"name"`
+	Description   string                        `json:"description"`
+	Driver        types.SQLDriver               `json:"driver"`
+	Parameters    types.SQLConnectionParameters `json:"parameters"`
+	AllowedTables []string                      `json:"allowed_tables"`
+	MaxRows       int                           `json:"max_rows"`
+	QueryTimeout  int                           `json:"query_timeout"`
+}
+
+func (r *SQLConnectionRequest) toConnection(tenantID uint64) *types.SQLConnection {
+	return &types.SQLConnection{
+		TenantID:      tenantID,
+		Name:          secutils.SanitizeForLog(r.Name),
+		Description:   secutils.SanitizeForLog(r.Description),
+		Driver:        r.Driver,
+		Parameters:    r.Parameters,
+		AllowedTables: types.StringArray(r.AllowedTables),
+		MaxRows:       r.MaxRows,
+		QueryTimeout:  r.QueryTimeout,
+	}
+}
+
+// getTenantID extracts tenant ID from gin context (set by auth middleware).
+func (h *SQLConnectionHandler) getTenantID(c *gin.Context) uint64 {
+	return c.GetUint64(types.TenantIDContextKey.String())
+}
+
+// getOwnedConnection loads a connection and verifies it belongs to the given
+// tenant. Returns (nil, status, msg) on failure so callers can respond
+// immediately.
+func (h *SQLConnectionHandler) getOwnedConnection(
+	ctx context.Context, tenantID uint64, id string,
+) (*types.SQLConnection, int, string) {
+	conn, err := h.service.GetConnection(ctx, tenantID, id)
+	if err != nil {
+		return nil, http.StatusInternalServerError, "failed to query sql connection"
+	}
+	if conn == nil {
+		return nil, http.StatusNotFound, "sql connection not found"
+	}
+	return conn, http.StatusOK, ""
+}
+
+// serviceError reports err, keeping the status of application errors.
+func (h *SQLConnectionHandler) serviceError(c *gin.Context, err error) {
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	c.Error(errors.NewInternalServerError(err.Error()))
+}
+
+// CreateConnection creates a new SQL connection.
+//
+// CreateConnection godoc
+// @Summary      创建 SQL 数据库连接
+// @Description  登记一个可供知识问答生成只读 SQL 查询的数据库
+// @Tags         SQL连接
+// @Accept       json
+// @Produce      json
+// @Param        request  body      handler.SQLConnectionRequest  true  "连接配置"
+// @Success      201      {object}  dto.SQLConnectionResponse     "创建的连接"
+// @Failure      400      {object}  map[string]interface{}        "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections [post]
+func (h *SQLConnectionHandler) CreateConnection(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: tenant context missing"})
+		return
+	}
+
+	var req SQLConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warnf(ctx, "Invalid create sql connection request: %v", err)
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	conn := req.toConnection(tenantID)
+	if err := h.service.CreateConnection(ctx, conn); err != nil {
+		logger.Warnf(ctx, "Failed to create sql connection: %v", err)
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    dto.NewSQLConnectionResponse(conn),
+	})
+}
+
+// ListConnections lists the SQL connections of the current tenant.
+//
+// ListConnections godoc
+// @Summary      获取 SQL 数据库连接列表
+// @Tags         SQL连接
+// @Produce      json
+// @Success      200  {array}   dto.SQLConnectionResponse  "连接列表"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections [get]
+func (h *SQLConnectionHandler) ListConnections(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: tenant context missing"})
+		return
+	}
+
+	conns, err := h.service.ListConnections(ctx, tenantID)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to list sql connections: %v", err)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dto.NewSQLConnectionResponses(conns),
+	})
+}
+
+// GetConnection retrieves a single SQL connection by ID.
+//
+// GetConnection godoc
+// @Summary      获取 SQL 数据库连接详情
+// @Tags         SQL连接
+// @Produce      json
+// @Param        id   path      string                     true  "连接 ID"
+// @Success      200  {object}  dto.SQLConnectionResponse  "连接详情"
+// @Failure      404  {object}  map[string]interface{}     "连接不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections/{id} [get]
+func (h *SQLConnectionHandler) GetConnection(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: tenant context missing"})
+		return
+	}
+
+	conn, status, msg := h.getOwnedConnection(ctx, tenantID, c.Param("id"))
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dto.NewSQLConnectionResponse(conn),
+	})
+}
+
+// UpdateConnection updates a SQL connection. The driver is immutable.
+//
+// UpdateConnection godoc
+// @Summary      更新 SQL 数据库连接
+// @Description  password 留空时保留已保存的密码
+// @Tags         SQL连接
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                        true  "连接 ID"
+// @Param        request  body      handler.SQLConnectionRequest  true  "连接配置"
+// @Success      200      {object}  dto.SQLConnectionResponse     "更新后的连接"
+// @Failure      400      {object}  map[string]interface{}        "请求参数错误"
+// @Failure      404      {object}  map[string]interface{}        "连接不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections/{id} [put]
+func (h *SQLConnectionHandler) UpdateConnection(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: tenant context missing"})
+		return
+	}
+
+	id := c.Param("id")
+	existing, status, msg := h.getOwnedConnection(ctx, tenantID, id)
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	var req SQLConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	conn := req.toConnection(tenantID)
+	conn.ID = id
+	conn.Driver = existing.Driver
+	conn.CreatedAt = existing.CreatedAt
+	if err := h.service.UpdateConnection(ctx, conn); err != nil {
+		logger.Warnf(ctx, "Failed to update sql connection %s: %v", secutils.SanitizeForLog(id), err)
+		h.serviceError(c, err)
+		return
+	}
+
+	updated, _ := h.service.GetConnection(ctx, tenantID, id)
+	if updated != nil {
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": dto.NewSQLConnectionResponse(updated)})
+	} else {
+		c.JSON(http.StatusOK, gin.H{"success": true})
+	}
+}
+
+// DeleteConnection deletes a SQL connection.
+//
+// DeleteConnection godoc
+// @Summary      删除 SQL 数据库连接
+// @Tags         SQL连接
+// @Produce      json
+// @Param        id   path      string                  true  "连接 ID"
+// @Success      200  {object}  map[string]interface{}  "success: true"
+// @Failure      404  {object}  map[string]interface{}  "连接不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections/{id} [delete]
+func (h *SQLConnectionHandler) DeleteConnection(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: tenant context missing"})
+		return
+	}
+
+	id := c.Param("id")
+	if _, status, msg := h.getOwnedConnection(ctx, tenantID, id); status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	if err := h.service.DeleteConnection(ctx, tenantID, id); err != nil {
+		logger.Warnf(ctx, "Failed to delete sql connection %s: %v", secutils.SanitizeForLog(id), err)
+		c.Error(errors.NewInternalServerError(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// TestConnectionByID tests an existing saved connection.
+//
+// TestConnectionByID godoc
+// @Summary      测试已保存的 SQL 数据库连接
+// @Tags         SQL连接
+// @Produce      json
+// @Param        id   path      string                  true  "连接 ID"
+// @Success      200  {object}  map[string]interface{}  "测试结果"
+// @Failure      404  {object}  map[string]interface{}  "连接不存在"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections/{id}/test [post]
+func (h *SQLConnectionHandler) TestConnectionByID(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: tenant context missing"})
+		return
+	}
+
+	conn, status, msg := h.getOwnedConnection(ctx, tenantID, c.Param("id"))
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	if err := h.service.TestConnection(ctx, conn); err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// TestConnectionRaw tests unsaved connection parameters (no persistence).
+//
+// TestConnectionRaw godoc
+// @Summary      使用未保存的参数测试 SQL 数据库连接
+// @Description  用于表单中的"测试连接"按钮
+// @Tags         SQL连接
+// @Accept       json
+// @Produce      json
+// @Param        request  body      handler.SQLConnectionRequest  true  "连接配置"
+// @Success      200      {object}  map[string]interface{}        "测试结果"
+// @Failure      400      {object}  map[string]interface{}        "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /sql-connections/test [post]
+func (h *SQLConnectionHandler) TestConnectionRaw(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var req SQLConnectionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	if err := h.service.TestConnection(ctx, req.toConnection(h.getTenantID(c))); err != nil {
+		c.JSON(http.StatusOK, gin.H{"success": false, "error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/database"
 	"github.com/Tencent/WeKnora/internal/infrastructure/docparser"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/runtime"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
@@ -116,6 +117,7 @@ type GetSystemInfoResponse struct {
 	KeywordIndexEngine  string `json:"keyword_index_engine,omitempty"`
 	VectorStoreEngine   string `json:"vector_store_engine,omitempty"`
 	GraphDatabaseEngine string `json:"graph_database_engine,omitempty"`
+	MemoryEngine        string `json:"memory_engine,omitempty"`
 	MinioEnabled        bool   `json:"minio_enabled,omitempty"`
 	DBVersion           string `json:"db_version,omitempty"`
 	// DBMigrationError carries the human-readable error message recorded when
@@ -155,7 +157,7 @@ func (h *SystemHandler) GetSystemInfo(c *gin.Context) {
 	// Get vector store engine from config or RETRIEVE_DRIVER
 	vectorStoreEngine := h.getVectorStoreEngine()
 
-	// Get graph database engine from NEO4J_ENABLE
+	// Get graph database engine from GRAPH_DRIVER and NEO4J_ENABLE
 	graphDatabaseEngine := h.getGraphDatabaseEngine()
 
 	// Get MinIO enabled status
@@ -194,6 +196,7 @@ func (h *SystemHandler) GetSystemInfo(c *gin.Context) {
 		KeywordIndexEngine:  keywordIndexEngine,
 		VectorStoreEngine:   vectorStoreEngine,
 		GraphDatabaseEngine: graphDatabaseEngine,
+		MemoryEngine:        h.getMemoryEngine(),
 		MinioEnabled:        minioEnabled,
 		DBVersion:           dbVersion,
 		DBMigrationError:    dbMigrationErr,
@@ -430,14 +433,32 @@ func (h *SystemHandler) getVectorStoreEngine() string {
 	return strings.Join(vectorEngines, ", ")
 }
 
-// getGraphDatabaseEngine returns the graph database engine name
+// getGraphDatabaseEngine returns where knowledge base graphs are stored,
+// following GRAPH_DRIVER the same way the container selects the graph repository
 func (h *SystemHandler) getGraphDatabaseEngine() string {
+	if strings.ToLower(strings.TrimSpace(os.Getenv("GRAPH_DRIVER"))) == "database" {
+		return "Database"
+	}
+	return h.getNeo4jEngine()
+}
+
+// getNeo4jEngine returns the Neo4j engine name when a driver is connected
+func (h *SystemHandler) getNeo4jEngine() string {
 	if h.neo4jDriver == nil {
 		return "Not Enabled"
 	}
 	return "Neo4j"
 }
 
+// getMemoryEngine returns where conversation memory is stored, following
+// MEMORY_DRIVER the same way the container selects the memory repository
+func (h *SystemHandler) getMemoryEngine() string {
+	if strings.ToLower(strings.TrimSpace(os.Getenv("MEMORY_DRIVER"))) == "database" {
+		return "Database"
+	}
+	return h.getNeo4jEngine()
+}
+
 // supportsRetrieverType checks if a driver supports a specific retriever type
 // by looking up the retrieverEngineMapping from types package
 func (h *SystemHandler) supportsRetrieverType(driver string, retrieverType types.RetrieverType) bool {
@@ -532,6 +553,17 @@ func (h *SystemHandler) isKS3Configured(c *gin.Context) bool {
 	return false
 }
 
+// isGCSConfigured checks whether GCS connection info is available from tenant config.
+func (h *SystemHandler) isGCSConfigured(c *gin.Context) bool {
+	if v, exists := c.Get(types.TenantInfoContextKey.String()); exists {
+		if tenant, ok := v.(*types.Tenant); ok && tenant != nil && tenant.StorageEngineConfig != nil && tenant.StorageEngineConfig.GCS != nil {
+			gcsConf := tenant.StorageEngineConfig.GCS
+			return gcsConf.BucketName != "" && gcsConf.CredentialsJSON != ""
+		}
+	}
+	return false
+}
+
 // isTOSEnvAvailable checks whether TOS env vars are set.
 func (h *SystemHandler) isTOSEnvAvailable() bool {
 	return os.Getenv("TOS_ENDPOINT") != "" &&
@@ -571,6 +603,7 @@ func (h *SystemHandler) GetStorageEngineStatus(c *gin.Context) {
 	s3Configured := h.isS3Configured(c)
 	ossConfigured := h.isOSSConfigured(c)
 	ks3Configured := h.isKS3Configured(c)
+	gcsConfigured := h.isGCSConfigured(c)
 	allowed := getAllowedStorageProviders()
 	allowedProviders := make([]string, 0, len(supportedStorageProviders))
 	for _, provider := range getSupportedStorageProviders() {
@@ -586,6 +619,7 @@ func (h *SystemHandler) GetStorageEngineStatus(c *gin.Context) {
 		{Name: "s3", Allowed: allowed["s3"], Available: s3Configured, Description: "AWS S3 与兼容对象存储服务，适合公有云与混合云部署"},
 		{Name: "oss", Allowed: allowed["oss"], Available: ossConfigured, Description: "阿里云对象存储服务，适合公有云部署，支持 S3 兼容协议"},
 		{Name: "ks3", Allowed: allowed["ks3"], Available: ks3Configured, Description: "金山云对象存储服务，适合公有云部署"},
+		{Name: "gcs", Allowed: allowed["gcs"], Available: gcsConfigured, Description: "Google Cloud Storage，适合部署在 Google Cloud 上的场景"},
 	}
 	c.JSON(200, gin.H{
 		"code": 0,
@@ -688,7 +722,7 @@ func isBlockedStorageEndpoint(endpoint string) (bool, string) {
 
 // StorageCheckRequest is the body for POST /system/storage-engine-check.
 type StorageCheckRequest struct {
-	Provider string                   `json:"provider"` // "minio", "cos", "tos", "s3", "oss", "ks3", "obs"
+	Provider string                   `json:"provider"` // "minio", "cos", "tos", "s3", "oss", "ks3", "obs", "gcs"
 	MinIO    *types.MinIOEngineConfig `json:"minio,omitempty"`
 	COS      *types.COSEngineConfig   `json:"cos,omitempty"`
 	TOS      *types.TOSEngineConfig   `json:"tos,omitempty"`
@@ -696,6 +730,7 @@ type StorageCheckRequest struct {
 	OSS      *types.OSSEngineConfig   `json:"oss,omitempty"`
 	KS3      *types.KS3EngineConfig   `json:"ks3,omitempty"`
 	OBS      *types.OBSEngineConfig   `json:"obs,omitempty"`
+	GCS      *types.GCSEngineConfig   `json:"gcs,omitempty"`
 }
 
 // StorageCheckResponse is the response for a single-engine connectivity check.
@@ -742,6 +777,8 @@ func (h *SystemHandler) CheckStorageEngine(c *gin.Context) {
 		h.checkKS3(c, ctx, req.KS3)
 	case "obs":
 		h.checkOBS(c, ctx, req.OBS)
+	case "gcs":
+		h.checkGCS(c, ctx, req.GCS)
 	default:
 		c.JSON(200, gin.H{"code": 0, "data": StorageCheckResponse{OK: true, Message: "本地存储无需检测"}})
 	}
@@ -908,7 +945,7 @@ func (h *SystemHandler) checkS3(c *gin.Context, ctx context.Context, cfg *types.
 		return
 	}
 
-	err := file.CheckS3Connectivity(ctx, cfg.Endpoint, cfg.AccessKey, cfg.SecretKey, cfg.BucketName, cfg.Region)
+	err := file.CheckS3Connectivity(ctx, cfg.Endpoint, cfg.AccessKey, cfg.SecretKey, cfg.BucketName, cfg.Region, cfg.ForcePathStyle)
 	if err != nil {
 		logger.Errorf(ctx, "Storage check: S3 connectivity failed, bucket: %s, error: %v", cfg.BucketName, err)
 		errMsg := err.Error()
@@ -1057,6 +1094,42 @@ func (h *SystemHandler) checkOBS(c *gin.Context, ctx context.Context, cfg *types
 	c.JSON(200, gin.H{"code": 0, "data": StorageCheckResponse{OK: true, Message: fmt.Sprintf("连接成功，Bucket「%s」已确认存在", cfg.BucketName)}})
 }
 
+func (h *SystemHandler) checkGCS(c *gin.Context, ctx context.Context, cfg *types.GCSEngineConfig) {
+	if cfg == nil {
+		c.JSON(200, gin.H{"code": 0, "data": StorageCheckResponse{OK: false, Message: "未提供 GCS 配置"}})
+		return
+	}
+	if cfg.BucketName == "" || cfg.CredentialsJSON == "" {
+		c.JSON(200, gin.H{"code": 0, "data": StorageCheckResponse{OK: false, Message: "Bucket 名称、服务账号密钥 JSON 不能为空"}})
+		return
+	}
+	if !ossFieldPattern.MatchString(cfg.BucketName) {
+		c.JSON(200, gin.H{"code": 0, "data": StorageCheckResponse{OK: false, Message: "Bucket 名称格式不正确，仅允许字母、数字、点、连字符"}})
+		return
+	}
+
+	err := file.CheckGCSConnectivity(ctx, cfg.BucketName, cfg.CredentialsJSON)
+	if err != nil {
+		logger.Errorf(ctx, "Storage check: GCS connectivity failed, bucket: %s, error: %v", cfg.BucketName, err)
+		errMsg := err.Error()
+		if strings.Contains(errMsg, "credentials") || strings.Contains(errMsg, "private key") {
+			c.JSON(200, gin.H{"code": 0, "data": StorageCheckResponse{OK: false, Message: "服务账号密钥 JSON 无效，请上传 service_account 类型的密钥文件"}})
+			return
+		}
+		if strings.Contains(errMsg, "401") || strings.Contains(errMsg, "403") {
+			c.JSON(200, gin.H{"code": 0, "data": StorageCheckResponse{OK: false, Message: "认证失败，请检查服务账号是否有该 Bucket 的访问权限"}})
+			return
+		}
+		if strings.Contains(errMsg, "does not exist") {
+			c.JSON(200, gin.H{"code": 0, "data": StorageCheckResponse{OK: false, Message: fmt.Sprintf("Bucket「%s」不存在", cfg.BucketName)}})
+			return
+		}
+		c.JSON(200, gin.H{"code": 0, "data": StorageCheckResponse{OK: false, Message: sanitizeStorageCheckError(err)}})
+		return
+	}
+	c.JSON(200, gin.H{"code": 0, "data": StorageCheckResponse{OK: true, Message: fmt.Sprintf("连接成功，Bucket「%s」已确认存在", cfg.BucketName)}})
+}
+
 func (h *SystemHandler) ResolveDocumentReader(ctx context.Context, addr string) interfaces.DocumentReader {
 	if addr == "" {
 		return h.documentReader
@@ -1581,3 +1654,17 @@ func (h *SystemHandler) ResetSystemSetting(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
+
+// GetEmbeddingStats godoc
+// @Summary      Batch embedding throughput
+// @Description  Process-wide counters of the batch embedding used during
+// @Description  ingestion: texts, batches, retries, splits and texts per
+// @Description  second since the server started. SystemAdmin only.
+// @Tags         System Admin
+// @Produce      json
+// @Success      200 {object} embedding.BatchStats "batch embedding counters"
+// @Failure      403 {object} map[string]interface{} "Forbidden: not a system admin"
+// @Router       /system/admin/embedding-stats [get]
+func (h *SystemHandler) GetEmbeddingStats(c *gin.Context) {
+	c.JSON(http.StatusOK, embedding.GetBatchStats())
+}
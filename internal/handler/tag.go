@@ -113,16 +113,17 @@ type createTagRequest struct {
 	Name      string `json:"name"       binding:"required"`
 	Color     string `json:"color"`
 	SortOrder int    `json:"sort_order"`
+	ParentID  string `json:"parent_id"`
 }
 
 // CreateTag godoc
 // @Summary      创建标签
-// @Description  在知识库下创建新标签
+// @Description  在知识库下创建新标签，指定 parent_id 时创建为该标签的子标签
 // @Tags         标签管理
 // @Accept       json
 // @Produce      json
 // @Param        id       path      string  true  "知识库ID"
-// @Param        request  body      object{name=string,color=string,sort_order=int}  true  "标签信息"
+// @Param        request  body      object{name=string,color=string,sort_order=int,parent_id=string}  true  "标签信息"
 // @Success      200      {object}  map[string]interface{}  "创建的标签"
 // @Failure      400      {object}  errors.AppError         "请求参数错误"
 // @Security     Bearer
@@ -140,7 +141,7 @@ func (h *TagHandler) CreateTag(c *gin.Context) {
 	}
 
 	tag, err := h.tagService.CreateTag(ctx, kbID,
-		secutils.SanitizeForLog(req.Name), secutils.SanitizeForLog(req.Color), req.SortOrder)
+		secutils.SanitizeForLog(req.Name), secutils.SanitizeForLog(req.Color), req.SortOrder, req.ParentID)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"kb_id": kbID,
@@ -159,11 +160,13 @@ type updateTagRequest struct {
 	Name      *string `json:"name"`
 	Color     *string `json:"color"`
 	SortOrder *int    `json:"sort_order"`
+	// ParentID moves the tag; an empty string moves it to the top level
+	ParentID *string `json:"parent_id"`
 }
 
 // UpdateTag godoc
 // @Summary      更新标签
-// @Description  更新标签信息
+// @Description  更新标签信息；传入 parent_id 可移动标签，空字符串表示移到顶层
 // @Tags         标签管理
 // @Accept       json
 // @Produce      json
@@ -191,7 +194,7 @@ func (h *TagHandler) UpdateTag(c *gin.Context) {
 		return
 	}
 
-	tag, err := h.tagService.UpdateTag(ctx, tagID, req.Name, req.Color, req.SortOrder)
+	tag, err := h.tagService.UpdateTag(ctx, tagID, req.Name, req.Color, req.SortOrder, req.ParentID)
 	if err != nil {
 		logger.ErrorWithFields(ctx, err, map[string]interface{}{
 			"tag_id": tagID,
@@ -336,7 +336,7 @@ func (h *TenantHandler) CreateTenant(c *gin.Context) {
 
 // GetTenant godoc
 // @Summary      获取租户详情
-// @Description  根据ID获取租户详情
+// @Description  根据ID获取租户详情。已弃用的旧版租户 API Key（api_key）仅返回给该租户以账号登录的 Owner，其他调用方（包括任何 API Key 请求）拿到的是空值；集成请改用托管 API Key（/api-keys）
 // @Tags         租户管理
 // @Accept       json
 // @Produce      json
@@ -371,7 +371,7 @@ func (h *TenantHandler) GetTenant(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    tenant,
+		"data":    tenant.RedactedFor(ctx),
 	})
 }
 
@@ -462,7 +462,7 @@ func (h *TenantHandler) UpdateTenant(c *gin.Context) {
 	)
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    updatedTenant,
+		"data":    updatedTenant.RedactedFor(ctx),
 	})
 }
 
@@ -510,6 +510,63 @@ func (h *TenantHandler) ResetAPIKey(c *gin.Context) {
 	})
 }
 
+// UpdateAPIKeyRoleRequest is the body of UpdateAPIKeyRole
+type UpdateAPIKeyRoleRequest struct {
+	Role types.TenantRole `json:"role" binding:"required"`
+}
+
+// UpdateAPIKeyRole godoc
+// @Summary      设置租户 API Key 角色
+// @Description  设置通过租户 API Key 认证的请求所获得的角色（viewer、contributor 或 admin），不能设为 owner
+// @Tags         租户管理
+// @Accept       json
+// @Produce      json
+// @Param        id       path      int                      true  "租户ID"
+// @Param        request  body      UpdateAPIKeyRoleRequest  true  "API Key 角色"
+// @Success      200      {object}  map[string]interface{}   "更新后的租户"
+// @Failure      400      {object}  errors.AppError          "请求参数错误"
+// @Failure      403      {object}  errors.AppError          "权限不足"
+// @Security     Bearer
+// @Router       /tenants/{id}/api-key/role [put]
+func (h *TenantHandler) UpdateAPIKeyRole(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Errorf(ctx, "Invalid tenant ID: %s", secutils.SanitizeForLog(c.Param("id")))
+		c.Error(errors.NewBadRequestError("Invalid tenant ID"))
+		return
+	}
+
+	var req UpdateAPIKeyRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request parameters").WithDetails(err.Error()))
+		return
+	}
+
+	logger.Infof(ctx, "Updating API key role for tenant, ID: %d, role: %s",
+		id, secutils.SanitizeForLog(string(req.Role)))
+	tenant, err := h.service.UpdateAPIKeyRole(ctx, id, req.Role)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			logger.Error(ctx, "Failed to update API key role: application error", appErr)
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update API key role").WithDetails(err.Error()))
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"api_key_role": tenant.EffectiveAPIKeyRole(),
+		},
+	})
+}
+
 // DeleteTenant godoc
 // @Summary      删除租户
 // @Description  删除指定的租户
@@ -575,7 +632,7 @@ func (h *TenantHandler) ListTenants(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"items": []*types.Tenant{tenant},
+			"items": []*types.Tenant{tenant.RedactedFor(ctx)},
 		},
 	})
 }
@@ -612,7 +669,7 @@ func (h *TenantHandler) ListAllTenants(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"items": tenants,
+			"items": redactTenants(ctx, tenants),
 		},
 	})
 }
@@ -682,7 +739,7 @@ func (h *TenantHandler) SearchTenants(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"data": gin.H{
-			"items":     tenants,
+			"items":     redactTenants(ctx, tenants),
 			"total":     total,
 			"page":      page,
 			"page_size": pageSize,
@@ -690,9 +747,18 @@ func (h *TenantHandler) SearchTenants(c *gin.Context) {
 	})
 }
 
+// redactTenants applies Tenant.RedactedFor to every tenant of a listing.
+func redactTenants(ctx context.Context, tenants []*types.Tenant) []*types.Tenant {
+	redacted := make([]*types.Tenant, len(tenants))
+	for i, t := range tenants {
+		redacted[i] = t.RedactedFor(ctx)
+	}
+	return redacted
+}
+
 // GetTenantKV godoc
 // @Summary      获取租户KV配置
-// @Description  获取租户级别的KV配置（支持web-search-config、prompt-templates、parser-engine-config、storage-engine-config、chat-history-config、retrieval-config）
+// @Description  获取租户级别的KV配置（支持web-search-config、prompt-templates、parser-engine-config、storage-engine-config、chat-history-config、retrieval-config、memory-extraction-config、guardrails-config、pipeline-config）
 // @Tags         租户管理
 // @Accept       json
 // @Produce      json
@@ -725,6 +791,15 @@ func (h *TenantHandler) GetTenantKV(c *gin.Context) {
 	case "retrieval-config":
 		h.GetTenantRetrievalConfig(c)
 		return
+	case "memory-extraction-config":
+		h.GetTenantMemoryExtractionConfig(c)
+		return
+	case "guardrails-config":
+		h.GetTenantGuardrailsConfig(c)
+		return
+	case "pipeline-config":
+		h.GetTenantPipelineConfig(c)
+		return
 	default:
 		logger.Info(ctx, "KV key not supported", "key", key)
 		c.Error(errors.NewBadRequestError("unsupported key"))
@@ -734,7 +809,7 @@ func (h *TenantHandler) GetTenantKV(c *gin.Context) {
 
 // UpdateTenantKV godoc
 // @Summary      更新租户KV配置
-// @Description  更新租户级别的KV配置（支持web-search-config、parser-engine-config、storage-engine-config、chat-history-config、retrieval-config）
+// @Description  更新租户级别的KV配置（支持web-search-config、parser-engine-config、storage-engine-config、chat-history-config、retrieval-config、memory-extraction-config、guardrails-config、pipeline-config）
 // @Tags         租户管理
 // @Accept       json
 // @Produce      json
@@ -765,6 +840,15 @@ func (h *TenantHandler) UpdateTenantKV(c *gin.Context) {
 	case "retrieval-config":
 		h.updateTenantRetrievalConfigInternal(c)
 		return
+	case "memory-extraction-config":
+		h.updateTenantMemoryExtractionConfigInternal(c)
+		return
+	case "guardrails-config":
+		h.updateTenantGuardrailsConfigInternal(c)
+		return
+	case "pipeline-config":
+		h.updateTenantPipelineConfigInternal(c)
+		return
 	default:
 		logger.Info(ctx, "KV key not supported", "key", key)
 		c.Error(errors.NewBadRequestError("unsupported key"))
@@ -1152,6 +1236,10 @@ func (h *TenantHandler) updateTenantRetrievalConfigInternal(c *gin.Context) {
 		c.Error(errors.NewBadRequestError("rerank_top_k must be between 0 and 200"))
 		return
 	}
+	if cfg.DegradationPolicy != "" && !cfg.DegradationPolicy.IsValid() {
+		c.Error(errors.NewBadRequestError("degradation_policy must be one of: fail, keyword_fallback, unavailable"))
+		return
+	}
 
 	tenant, _ := types.TenantInfoFromContext(ctx)
 	if tenant == nil {
@@ -1177,3 +1265,186 @@ func (h *TenantHandler) updateTenantRetrievalConfigInternal(c *gin.Context) {
 		"message": "Retrieval configuration updated successfully",
 	})
 }
+
+// GetTenantMemoryExtractionConfig returns the tenant's memory extraction
+// overrides.
+func (h *TenantHandler) GetTenantMemoryExtractionConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+	data := tenant.MemoryExtractionConfig
+	if data == nil {
+		data = &types.MemoryExtractionConfig{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// updateTenantMemoryExtractionConfigInternal updates the tenant's memory
+// extraction prompt, entity type taxonomy and output schema.
+func (h *TenantHandler) updateTenantMemoryExtractionConfigInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var cfg types.MemoryExtractionConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	tenant.MemoryExtractionConfig = &cfg
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update memory extraction config").WithDetails(err.Error()))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedTenant.MemoryExtractionConfig,
+		"message": "Memory extraction configuration updated successfully",
+	})
+}
+
+// GetTenantGuardrailsConfig returns the tenant's prompt-injection and PII
+// filtering config.
+func (h *TenantHandler) GetTenantGuardrailsConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+	data := tenant.GuardrailsConfig
+	if data == nil {
+		data = &types.GuardrailsConfig{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// updateTenantGuardrailsConfigInternal updates the tenant's guardrails
+// actions, patterns and classifier model.
+func (h *TenantHandler) updateTenantGuardrailsConfigInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var cfg types.GuardrailsConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	tenant.GuardrailsConfig = &cfg
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update guardrails config").WithDetails(err.Error()))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedTenant.GuardrailsConfig,
+		"message": "Guardrails configuration updated successfully",
+	})
+}
+
+// GetTenantPipelineConfig returns the tenant's per-stage knowledge QA
+// pipeline config.
+func (h *TenantHandler) GetTenantPipelineConfig(c *gin.Context) {
+	ctx := c.Request.Context()
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+	data := tenant.PipelineConfig
+	if data == nil {
+		data = &types.PipelineConfig{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// updateTenantPipelineConfigInternal updates the tenant's per-stage
+// pipeline config, which applies over the agent's settings.
+func (h *TenantHandler) updateTenantPipelineConfigInternal(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var cfg types.PipelineConfig
+	if err := c.ShouldBindJSON(&cfg); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	if err := cfg.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	tenant.PipelineConfig = &cfg
+	updatedTenant, err := h.service.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update pipeline config").WithDetails(err.Error()))
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    updatedTenant.PipelineConfig,
+		"message": "Pipeline configuration updated successfully",
+	})
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/Tencent/WeKnora/internal/types/interfaces"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// TokenUsageHandler handles HTTP requests for token usage reports and
+// tenant token budgets
+type TokenUsageHandler struct {
+	service       interfaces.TokenUsageService
+	tenantService interfaces.TenantService
+}
+
+// NewTokenUsageHandler creates a new handler
+func NewTokenUsageHandler(
+	service interfaces.TokenUsageService, tenantService interfaces.TenantService,
+) *TokenUsageHandler {
+	return &TokenUsageHandler{service: service, tenantService: tenantService}
+}
+
+// serviceError reports err, keeping the status of application errors.
+func (h *TokenUsageHandler) serviceError(c *gin.Context, err error) {
+	if appErr, ok := errors.IsAppError(err); ok {
+		c.Error(appErr)
+		return
+	}
+	c.Error(errors.NewInternalServerError(err.Error()))
+}
+
+// GetUsage returns the token usage of the current tenant over a date range.
+//
+// GetUsage godoc
+// @Summary      获取 token 用量统计
+// @Description  按天、模型或会话汇总当前租户的 token 用量，日期为 UTC，默认最近 30 天
+// @Tags         用量统计
+// @Produce      json
+// @Param        start_date  query     string  false  "开始日期（YYYY-MM-DD）"
+// @Param        end_date    query     string  false  "结束日期（YYYY-MM-DD，含当天）"
+// @Param        group_by    query     string  false  "分组维度：day、model、session"
+// @Param        model_id    query     string  false  "只统计该模型"
+// @Param        session_id  query     string  false  "只统计该会话"
+// @Success      200         {object}  types.TokenUsageReport  "用量统计"
+// @Failure      400         {object}  errors.AppError         "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /usage [get]
+func (h *TokenUsageHandler) GetUsage(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := c.GetUint64(types.TenantIDContextKey.String())
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: tenant context missing"})
+		return
+	}
+
+	report, err := h.service.GetReport(ctx, &types.TokenUsageQuery{
+		TenantID:  tenantID,
+		StartDay:  secutils.SanitizeForLog(c.Query("start_date")),
+		EndDay:    secutils.SanitizeForLog(c.Query("end_date")),
+		GroupBy:   types.TokenUsageGroupBy(secutils.SanitizeForLog(c.Query("group_by"))),
+		ModelID:   secutils.SanitizeForLog(c.Query("model_id")),
+		SessionID: secutils.SanitizeForLog(c.Query("session_id")),
+	})
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get token usage report: %v", err)
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// GetBudget returns the token budget of the current tenant and the tokens
+// it used today and this month.
+//
+// GetBudget godoc
+// @Summary      获取 token 预算
+// @Tags         用量统计
+// @Produce      json
+// @Success      200  {object}  types.TokenBudgetStatus  "预算与已用量"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /usage/budget [get]
+func (h *TokenUsageHandler) GetBudget(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	status, err := h.service.GetBudgetStatus(ctx, tenant)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get token budget status: %v", err)
+		h.serviceError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    status,
+	})
+}
+
+// UpdateBudget sets the token budget of the current tenant. A zero limit
+// lifts that limit.
+//
+// UpdateBudget godoc
+// @Summary      更新 token 预算
+// @Description  设置当前租户每天（UTC）与每月的 token 上限，0 表示不限制；用尽后对话与向量化请求会被拒绝
+// @Tags         用量统计
+// @Accept       json
+// @Produce      json
+// @Param        request  body      types.TokenBudget        true  "预算"
+// @Success      200      {object}  types.TokenBudgetStatus  "预算与已用量"
+// @Failure      400      {object}  errors.AppError          "请求参数错误"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /usage/budget [put]
+func (h *TokenUsageHandler) UpdateBudget(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	var budget types.TokenBudget
+	if err := c.ShouldBindJSON(&budget); err != nil {
+		logger.Error(ctx, "Failed to parse request parameters", err)
+		c.Error(errors.NewValidationError("Invalid request data").WithDetails(err.Error()))
+		return
+	}
+	if err := budget.Validate(); err != nil {
+		c.Error(errors.NewBadRequestError(err.Error()))
+		return
+	}
+
+	tenant, _ := types.TenantInfoFromContext(ctx)
+	if tenant == nil {
+		logger.Error(ctx, "Tenant is empty")
+		c.Error(errors.NewBadRequestError("Tenant is empty"))
+		return
+	}
+
+	// Zero limits are stored as such: a nil budget would be skipped by the
+	// update and keep the old limits.
+	tenant.TokenBudget = &budget
+	updatedTenant, err := h.tenantService.UpdateTenant(ctx, tenant)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			c.Error(appErr)
+		} else {
+			logger.ErrorWithFields(ctx, err, nil)
+			c.Error(errors.NewInternalServerError("Failed to update token budget").WithDetails(err.Error()))
+		}
+		return
+	}
+	logger.Infof(ctx, "Token budget of tenant %d set to daily=%d, monthly=%d",
+		updatedTenant.ID, budget.DailyTokens, budget.MonthlyTokens)
+
+	status, err := h.service.GetBudgetStatus(ctx, updatedTenant)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get token budget status: %v", err)
+		h.serviceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    status,
+		"message": "Token budget updated successfully",
+	})
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"net/http"
 	"os"
 
@@ -338,6 +339,320 @@ func (h *VectorStoreHandler) DeleteStore(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
+// ReindexStore godoc
+// @Summary      Reindex vector store collections
+// @Description  Rebuild the store's collections with its current index_config (e.g. Milvus analyzer settings). Pause ingestion while this runs; rows written during the copy are not carried over.
+// @Tags         VectorStore
+// @Produce      json
+// @Param        id   path      string  true  "Vector store ID"
+// @Success      200  {object}  map[string]interface{}   "Reindex report"
+// @Failure      400  {object}  map[string]interface{}   "Env store or engine without reindex support"
+// @Failure      401  {object}  map[string]interface{}   "Unauthorized"
+// @Failure      404  {object}  map[string]interface{}   "Vector store not found"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /vector-stores/{id}/reindex [post]
+func (h *VectorStoreHandler) ReindexStore(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: tenant context missing"})
+		return
+	}
+
+	id := c.Param("id")
+
+	// env stores are read-only
+	if types.IsEnvStoreID(id) {
+		c.JSON(http.StatusBadRequest, envStoreReadonlyError())
+		return
+	}
+
+	store, status, msg := h.getOwnedStore(ctx, tenantID, id)
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	report, err := h.service.ReindexStore(ctx, store)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to reindex vector store %s: %v", id, err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": report.Failed() == 0, "data": report})
+}
+
+// DedupStore godoc
+// @Summary      Remove duplicate index rows
+// @Description  Rewrite every row of the store under its deterministic ID so duplicates left by retried writes collapse into one row. Safe to re-run.
+// @Tags         VectorStore
+// @Produce      json
+// @Param        id   path      string  true  "Vector store ID"
+// @Success      200  {object}  map[string]interface{}   "Dedup report"
+// @Failure      400  {object}  map[string]interface{}   "Env store or engine without dedup support"
+// @Failure      401  {object}  map[string]interface{}   "Unauthorized"
+// @Failure      404  {object}  map[string]interface{}   "Vector store not found"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /vector-stores/{id}/dedup [post]
+func (h *VectorStoreHandler) DedupStore(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: tenant context missing"})
+		return
+	}
+
+	id := c.Param("id")
+
+	// env stores are shared across tenants and read-only
+	if types.IsEnvStoreID(id) {
+		c.JSON(http.StatusBadRequest, envStoreReadonlyError())
+		return
+	}
+
+	store, status, msg := h.getOwnedStore(ctx, tenantID, id)
+	if status != http.StatusOK {
+		c.JSON(status, gin.H{"success": false, "error": msg})
+		return
+	}
+
+	report, err := h.service.DedupStore(ctx, store)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to deduplicate vector store %s: %v", id, err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": report.Failed() == 0, "data": report})
+}
+
+// MigrateStoreModelSpaces godoc
+// @Summary      Migrate vectors into per-model collections
+// @Description  Move the tenant's vectors out of legacy per-dimension collections into one collection per embedding model, so models sharing a dimension no longer share a vector space. Env stores are allowed; only the calling tenant's knowledge bases are moved.
+// @Tags         VectorStore
+// @Produce      json
+// @Param        id   path      string  true  "Vector store ID"
+// @Success      200  {object}  map[string]interface{}   "Migration report"
+// @Failure      400  {object}  map[string]interface{}   "Engine without per-model collections"
+// @Failure      401  {object}  map[string]interface{}   "Unauthorized"
+// @Failure      404  {object}  map[string]interface{}   "Vector store not found"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /vector-stores/{id}/migrate-model-spaces [post]
+func (h *VectorStoreHandler) MigrateStoreModelSpaces(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: tenant context missing"})
+		return
+	}
+
+	id := c.Param("id")
+
+	// Env stores are shared, but the migration only touches this tenant's
+	// rows, so they are accepted here unlike reindex.
+	var store *types.VectorStore
+	if types.IsEnvStoreID(id) {
+		store = types.FindEnvVectorStore(os.Getenv("RETRIEVE_DRIVER"), os.Getenv, id)
+		if store == nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "vector store not found"})
+			return
+		}
+	} else {
+		var status int
+		var msg string
+		store, status, msg = h.getOwnedStore(ctx, tenantID, id)
+		if status != http.StatusOK {
+			c.JSON(status, gin.H{"success": false, "error": msg})
+			return
+		}
+	}
+
+	report, err := h.service.MigrateStoreModelSpaces(ctx, tenantID, store)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to migrate model spaces of vector store %s: %v", id, err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": report.Failed() == 0, "data": report})
+}
+
+// validateFilterRequest carries the filter condition to dry-run.
+type validateFilterRequest struct {
+	Filter json.RawMessage `json:"filter" binding:"required"`
+}
+
+// ValidateFilter godoc
+// @Summary      Validate a metadata filter condition
+// @Description  Compile a filter condition against the store's schema without running a query. Returns the engine expression and parameter bindings, or the list of problems (unknown field, wrong value type, bad operator).
+// @Tags         VectorStore
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string                 true  "Vector store ID"
+// @Param        request  body      validateFilterRequest  true  "Filter condition"
+// @Success      200      {object}  map[string]interface{}   "Validation result"
+// @Failure      400      {object}  map[string]interface{}   "Missing filter or engine without filter support"
+// @Failure      401      {object}  map[string]interface{}   "Unauthorized"
+// @Failure      404      {object}  map[string]interface{}   "Vector store not found"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /vector-stores/{id}/validate-filter [post]
+func (h *VectorStoreHandler) ValidateFilter(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: tenant context missing"})
+		return
+	}
+
+	var req validateFilterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(errors.NewBadRequestError("filter is required").WithDetails(err.Error()))
+		return
+	}
+
+	id := c.Param("id")
+
+	// Validation only compiles the filter, so shared env stores are fine.
+	var store *types.VectorStore
+	if types.IsEnvStoreID(id) {
+		store = types.FindEnvVectorStore(os.Getenv("RETRIEVE_DRIVER"), os.Getenv, id)
+		if store == nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "vector store not found"})
+			return
+		}
+	} else {
+		var status int
+		var msg string
+		store, status, msg = h.getOwnedStore(ctx, tenantID, id)
+		if status != http.StatusOK {
+			c.JSON(status, gin.H{"success": false, "error": msg})
+			return
+		}
+	}
+
+	result, err := h.service.ValidateFilter(ctx, store, req.Filter)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to validate filter on vector store %s: %v", id, err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
+}
+
+// GetStoreCapabilities godoc
+// @Summary      Get vector store engine capabilities
+// @Description  Report what the engine serving the store supports: retriever types, hybrid search, filter operators and nesting depth, partial updates and the top-k limit. Clients use it to adapt queries instead of discovering limits through errors.
+// @Tags         VectorStore
+// @Produce      json
+// @Param        id   path      string                 true  "Vector store ID"
+// @Success      200  {object}  map[string]interface{}   "Engine capabilities"
+// @Failure      400  {object}  map[string]interface{}   "Vector store not registered"
+// @Failure      401  {object}  map[string]interface{}   "Unauthorized"
+// @Failure      404  {object}  map[string]interface{}   "Vector store not found"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /vector-stores/{id}/capabilities [get]
+func (h *VectorStoreHandler) GetStoreCapabilities(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: tenant context missing"})
+		return
+	}
+
+	id := c.Param("id")
+
+	// Capabilities are read-only, so shared env stores are fine.
+	var store *types.VectorStore
+	if types.IsEnvStoreID(id) {
+		store = types.FindEnvVectorStore(os.Getenv("RETRIEVE_DRIVER"), os.Getenv, id)
+		if store == nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "vector store not found"})
+			return
+		}
+	} else {
+		var status int
+		var msg string
+		store, status, msg = h.getOwnedStore(ctx, tenantID, id)
+		if status != http.StatusOK {
+			c.JSON(status, gin.H{"success": false, "error": msg})
+			return
+		}
+	}
+
+	result, err := h.service.GetCapabilities(ctx, store)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get capabilities of vector store %s: %v", id, err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": result})
+}
+
+// GetStoreLoadState godoc
+// @Summary      Get vector store collection load state
+// @Description  Report whether each collection of the store is loaded into memory, with load progress and the last load error. ready is true once every collection is loaded and searches no longer wait on a load.
+// @Tags         VectorStore
+// @Produce      json
+// @Param        id   path      string                 true  "Vector store ID"
+// @Success      200  {object}  map[string]interface{}   "Load state report"
+// @Failure      400  {object}  map[string]interface{}   "Engine does not load collections, or store not registered"
+// @Failure      401  {object}  map[string]interface{}   "Unauthorized"
+// @Failure      404  {object}  map[string]interface{}   "Vector store not found"
+// @Security     Bearer
+// @Security     ApiKeyAuth
+// @Router       /vector-stores/{id}/load-state [get]
+func (h *VectorStoreHandler) GetStoreLoadState(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	tenantID := h.getTenantID(c)
+	if tenantID == 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "error": "unauthorized: tenant context missing"})
+		return
+	}
+
+	id := c.Param("id")
+
+	// Load state is read-only, so shared env stores are fine.
+	var store *types.VectorStore
+	if types.IsEnvStoreID(id) {
+		store = types.FindEnvVectorStore(os.Getenv("RETRIEVE_DRIVER"), os.Getenv, id)
+		if store == nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "error": "vector store not found"})
+			return
+		}
+	} else {
+		var status int
+		var msg string
+		store, status, msg = h.getOwnedStore(ctx, tenantID, id)
+		if status != http.StatusOK {
+			c.JSON(status, gin.H{"success": false, "error": msg})
+			return
+		}
+	}
+
+	report, err := h.service.GetLoadState(ctx, store)
+	if err != nil {
+		logger.Warnf(ctx, "Failed to get load state of vector store %s: %v", id, err)
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": report})
+}
+
 // ListStoreTypes godoc
 // @Summary      List vector store types
 // @Description  Return supported engine types with connection and index field schemas for UI form generation
@@ -87,7 +87,9 @@ var storageSchemeRe = regexp.MustCompile(`\b(local|minio|s3|cos|tos|oss)://[^\s)
 //   - Successful rewrite logs at INFO with the full signed URL so operators
 //     can copy it out of logs and verify public reachability directly. The
 //     trade-off: anyone with log access can use a signed URL until it
-//     expires (WeKnora 2h, MinIO 24h). Acceptable for diagnosability.
+//     expires (WeKnora 2h, object stores 24h, unless the tenant's file_url
+//     config or FILE_URL_EXPIRY_SECONDS says otherwise). Acceptable for
+//     diagnosability.
 //   - Failure or no-op rewrite logs at WARN. The no-op case typically means
 //     APP_EXTERNAL_URL is not configured for the local backend, which is
 //     the most common cause of "image broken in IM" reports.
@@ -1969,6 +1971,20 @@ func (s *Service) handleMessageStream(ctx context.Context, msg *IncomingMessage,
 		return nil
 	})
 
+	// A regenerated answer (answer verification) replaces the answer streamed
+	// before it.
+	eventBus.On(event.EventAgentVerification, func(_ context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.AgentVerificationData)
+		if !ok || data.Verification == nil || !data.Verification.Regenerated {
+			return nil
+		}
+		bufMu.Lock()
+		answerOuter.Reset()
+		answerBuilder.Reset()
+		bufMu.Unlock()
+		return nil
+	})
+
 	eventBus.On(event.EventError, func(_ context.Context, evt event.Event) error {
 		data, ok := evt.Data.(event.ErrorData)
 		if !ok {
@@ -2290,6 +2306,16 @@ func (s *Service) runQA(ctx context.Context, session *types.Session, query strin
 		return nil
 	})
 
+	eventBus.On(event.EventAgentVerification, func(ctx context.Context, evt event.Event) error {
+		data, ok := evt.Data.(event.AgentVerificationData)
+		if ok && data.Verification != nil && data.Verification.Regenerated {
+			answerMu.Lock()
+			answerBuilder.Reset()
+			answerMu.Unlock()
+		}
+		return nil
+	})
+
 	eventBus.On(event.EventError, func(ctx context.Context, evt event.Event) error {
 		data, ok := evt.Data.(event.ErrorData)
 		if !ok {
@@ -0,0 +1,894 @@
+// Package chunker - code_splitter.go implements syntax-aware chunking of
+// source files: a file is cut at its top-level definitions (functions,
+// methods, classes, types) so that every chunk holds whole symbols and
+// records the symbol it belongs to.
+//
+// Go files are parsed with go/parser. The other languages go through a
+// lightweight scanner: comments and string literals are masked out, then
+// Python definitions are found by indentation and the brace languages
+// (JavaScript/TypeScript, Java, Kotlin, Scala, Swift, C/C++, C#, Rust, PHP)
+// by matching braces and classifying the declaration before each block.
+// The scanner needs no grammar files; declarations it cannot classify stay
+// in the text between symbols, which is chunked by lines.
+package chunker
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Tencent/WeKnora/internal/infrastructure/docparser"
+)
+
+// codeChunkSizeFactor lets a symbol run up to this many times ChunkSize and
+// still be kept whole: a function cut in the middle retrieves poorly. A
+// larger class is split into its members, a larger function by lines.
+const codeChunkSizeFactor = 2
+
+// Symbol kinds recorded in CodeInfo.Kind.
+const (
+	CodeKindFunction  = "function"
+	CodeKindMethod    = "method"
+	CodeKindClass     = "class"
+	CodeKindStruct    = "struct"
+	CodeKindInterface = "interface"
+	CodeKindType      = "type"
+)
+
+// CodeInfo describes the source file and symbol a code chunk was cut from.
+type CodeInfo struct {
+	Path     string // file path as given to SplitCode
+	Language string
+	// Symbol is the qualified name of the definition, e.g. "Server.Start".
+	// Empty for the code between definitions (imports, constants).
+	Symbol    string
+	Kind      string // CodeKind*, or the declaring keyword ("enum", "trait", "impl", ...)
+	StartLine int    // 1-based, inclusive
+	EndLine   int
+}
+
+// codeSymbol is a definition found in a source file. Offsets are runes;
+// the span includes the doc comments and decorators above it.
+type codeSymbol struct {
+	start, end int
+	name, kind string
+	// container is set for definitions whose body holds other definitions
+	// (classes, impl blocks, namespaces); members are found on demand.
+	container bool
+	bodyStart int // the first rune of the body, for containers
+	bodyEnd   int
+}
+
+// SplitCode splits a source file into chunks of whole definitions; the
+// language is told by the extension of filePath. Code between definitions
+// and oversized functions are cut between lines. Every chunk carries
+// CodeInfo, and its ContextHeader reads "path > Symbol". Files of an
+// unsupported language are only cut between lines.
+func SplitCode(text, filePath string, cfg SplitterConfig) []Chunk {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	cfg = ensureDefaults(cfg)
+	s := &codeSplitter{
+		runes:    []rune(text),
+		path:     filePath,
+		language: docparser.CodeLanguage(filePath),
+		size:     cfg.ChunkSize,
+	}
+	s.lineStarts = lineStarts(s.runes)
+	s.masked = maskCode(s.runes, s.language)
+
+	var symbols []codeSymbol
+	switch s.language {
+	case "go":
+		var ok bool
+		if symbols, ok = goSymbols(text); !ok {
+			symbols = s.braceSymbols(0, len(s.runes), false)
+		}
+	case "python":
+		symbols = s.pythonSymbols(0, len(s.lineStarts), -1)
+	case "":
+	default:
+		symbols = s.braceSymbols(0, len(s.runes), false)
+	}
+	s.emit(0, len(s.runes), symbols, nil)
+
+	for i := range s.out {
+		s.out[i].Seq = i
+	}
+	return s.out
+}
+
+type codeSplitter struct {
+	runes      []rune
+	masked     []rune // runes with comments and string literals blanked
+	lineStarts []int
+	// continuation caches continuationLines.
+	continuation []bool
+	path         string
+	language     string
+	size         int
+	out          []Chunk
+}
+
+// emit chunks runes[from:to], given the definitions found in it. Code
+// between definitions is attributed to parent, the enclosing container.
+func (s *codeSplitter) emit(from, to int, symbols []codeSymbol, parent *CodeInfo) {
+	pos := from
+	for _, sym := range symbols {
+		s.emitGap(pos, sym.start, parent)
+		info := s.info(sym, parent)
+		if sym.end-sym.start <= s.size*codeChunkSizeFactor && sym.kind != "namespace" && sym.kind != "module" {
+			s.emitLines(sym.start, sym.end, info)
+		} else if members := s.members(sym, info); sym.container && len(members) > 0 {
+			s.emit(sym.start, sym.end, members, &info)
+		} else {
+			s.emitLines(sym.start, sym.end, info)
+		}
+		pos = sym.end
+	}
+	s.emitGap(pos, to, parent)
+}
+
+// members finds the definitions in the body of a container.
+func (s *codeSplitter) members(sym codeSymbol, info CodeInfo) []codeSymbol {
+	if !sym.container {
+		return nil
+	}
+	var members []codeSymbol
+	if s.language == "python" {
+		from, to := s.lineOf(sym.bodyStart), s.lineOf(max(sym.bodyEnd-1, sym.bodyStart))+1
+		members = s.pythonSymbols(from, to, s.lineOf(sym.start))
+	} else {
+		members = s.braceSymbols(sym.bodyStart, sym.bodyEnd, info.Kind != "namespace" && info.Kind != "module")
+	}
+	for i := range members {
+		members[i].name = info.Symbol + "." + members[i].name
+	}
+	return members
+}
+
+func (s *codeSplitter) info(sym codeSymbol, parent *CodeInfo) CodeInfo {
+	kind := sym.kind
+	if parent != nil && kind == CodeKindFunction && parent.Kind != "namespace" && parent.Kind != "module" {
+		kind = CodeKindMethod
+	}
+	return CodeInfo{Path: s.path, Language: s.language, Symbol: sym.name, Kind: kind}
+}
+
+// emitGap emits the code between definitions. Blank stretches are
+// skipped; bare punctuation such as the closing brace of a container is
+// appended to the previous chunk rather than becoming a chunk of its own.
+func (s *codeSplitter) emitGap(from, to int, parent *CodeInfo) {
+	from, to = s.trim(from, to)
+	if from >= to {
+		return
+	}
+	if n := len(s.out); n > 0 && strings.Trim(string(s.runes[from:to]), " \t\r\n{}();,") == "" {
+		last := &s.out[n-1]
+		last.Content = string(s.runes[last.Start:to])
+		last.End = to
+		last.Code.EndLine = s.lineOf(to-1) + 1
+		return
+	}
+	info := CodeInfo{Path: s.path, Language: s.language}
+	if parent != nil {
+		info = *parent
+	}
+	s.emitLines(from, to, info)
+}
+
+// emitLines emits runes[from:to] as one chunk when it fits in
+// codeChunkSizeFactor * ChunkSize, or else packs whole lines into chunks of
+// at most ChunkSize runes; a single longer line is cut.
+func (s *codeSplitter) emitLines(from, to int, info CodeInfo) {
+	from, to = s.trim(from, to)
+	if from >= to {
+		return
+	}
+	if to-from <= s.size*codeChunkSizeFactor {
+		s.appendChunk(from, to, info)
+		return
+	}
+	start := from
+	for start < to {
+		end := min(start+s.size, to)
+		if end < to {
+			// Cut after the last newline of the window, if there is one.
+			for i := end - 1; i > start; i-- {
+				if s.runes[i] == '\n' {
+					end = i + 1
+					break
+				}
+			}
+		}
+		if a, b := s.trim(start, end); a < b {
+			s.appendChunk(a, b, info)
+		}
+		start = end
+	}
+}
+
+func (s *codeSplitter) appendChunk(from, to int, info CodeInfo) {
+	info.StartLine = s.lineOf(from) + 1
+	info.EndLine = s.lineOf(to-1) + 1
+	header := info.Path
+	if info.Symbol != "" {
+		if header != "" {
+			header += HeadingPathSeparator
+		}
+		header += info.Symbol
+	}
+	s.out = append(s.out, Chunk{
+		Content:       string(s.runes[from:to]),
+		ContextHeader: header,
+		Start:         from,
+		End:           to,
+		Code:          &info,
+	})
+}
+
+// trim narrows [from, to) to exclude surrounding whitespace.
+func (s *codeSplitter) trim(from, to int) (int, int) {
+	for from < to && unicode.IsSpace(s.runes[from]) {
+		from++
+	}
+	for to > from && unicode.IsSpace(s.runes[to-1]) {
+		to--
+	}
+	return from, to
+}
+
+// lineOf returns the 0-based line of a rune offset.
+func (s *codeSplitter) lineOf(offset int) int {
+	return sort.Search(len(s.lineStarts), func(i int) bool { return s.lineStarts[i] > offset }) - 1
+}
+
+// lineEnd returns the offset of the end of line i, its newline excluded.
+func (s *codeSplitter) lineEnd(i int) int {
+	if i+1 < len(s.lineStarts) {
+		return s.lineStarts[i+1] - 1
+	}
+	return len(s.runes)
+}
+
+func lineStarts(runes []rune) []int {
+	starts := []int{0}
+	for i, r := range runes {
+		if r == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return starts
+}
+
+// ---------------------------------------------------------------------------
+// Go
+// ---------------------------------------------------------------------------
+
+// goSymbols returns the functions, methods and type declarations of a Go
+// file, or false when the file does not parse.
+func goSymbols(text string) ([]codeSymbol, bool) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", text, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, false
+	}
+	toRune := byteToRuneOffsets(text)
+	span := func(doc *ast.CommentGroup, node ast.Node) (int, int) {
+		start := node.Pos()
+		if doc != nil {
+			start = doc.Pos()
+		}
+		return toRune(fset.Position(start).Offset), toRune(fset.Position(node.End()).Offset)
+	}
+
+	var symbols []codeSymbol
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			sym := codeSymbol{name: d.Name.Name, kind: CodeKindFunction}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				sym.name = receiverTypeName(d.Recv.List[0].Type) + "." + d.Name.Name
+				sym.kind = CodeKindMethod
+			}
+			sym.start, sym.end = span(d.Doc, d)
+			symbols = append(symbols, sym)
+		case *ast.GenDecl:
+			if d.Tok != token.TYPE || len(d.Specs) == 0 {
+				continue
+			}
+			sym := codeSymbol{kind: CodeKindType}
+			var names []string
+			for _, spec := range d.Specs {
+				names = append(names, spec.(*ast.TypeSpec).Name.Name)
+			}
+			sym.name = strings.Join(names, ", ")
+			if len(d.Specs) == 1 {
+				switch d.Specs[0].(*ast.TypeSpec).Type.(type) {
+				case *ast.StructType:
+					sym.kind = CodeKindStruct
+				case *ast.InterfaceType:
+					sym.kind = CodeKindInterface
+				}
+			}
+			sym.start, sym.end = span(d.Doc, d)
+			symbols = append(symbols, sym)
+		}
+	}
+	return symbols, true
+}
+
+// receiverTypeName returns the type name of a method receiver: "Server" for
+// "s *Server" or "l List[T]".
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	default:
+		return ""
+	}
+}
+
+// byteToRuneOffsets returns a function mapping byte offsets of text to rune
+// offsets.
+func byteToRuneOffsets(text string) func(int) int {
+	ascii := true
+	for i := 0; i < len(text); i++ {
+		if text[i] >= utf8.RuneSelf {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return func(b int) int { return b }
+	}
+	// Positions reported by go/parser are always rune starts.
+	offsets := make([]int, len(text)+1)
+	n := 0
+	for i := range text {
+		offsets[i] = n
+		n++
+	}
+	offsets[len(text)] = n
+	return func(b int) int { return offsets[b] }
+}
+
+// ---------------------------------------------------------------------------
+// Python
+// ---------------------------------------------------------------------------
+
+var pythonDefPattern = regexp.MustCompile(`^(?:async\s+)?(def|class)\s+([A-Za-z_]\w*)`)
+
+// pythonSymbols returns the definitions on lines [from, to) indented more
+// than the line outerLine (-1 for the module level), at the indentation of
+// the first statement.
+func (s *codeSplitter) pythonSymbols(from, to, outerLine int) []codeSymbol {
+	cont := s.continuationLines()
+	outerIndent := -1
+	if outerLine >= 0 {
+		outerIndent = s.indent(outerLine)
+	}
+	level := -1
+	var symbols []codeSymbol
+	prevEnd := from
+	for i := from; i < to; i++ {
+		if cont[i] || s.blankLine(i) {
+			continue
+		}
+		ind := s.indent(i)
+		if ind <= outerIndent {
+			break
+		}
+		if level < 0 {
+			level = ind
+		}
+		if ind != level {
+			continue
+		}
+		m := pythonDefPattern.FindStringSubmatch(strings.TrimSpace(s.maskedLine(i)))
+		if m == nil {
+			continue
+		}
+
+		// The body: following lines indented deeper, continuation lines and
+		// blank lines; it ends at the last non-blank one.
+		last := i
+		j := i + 1
+		for ; j < to; j++ {
+			if s.blankLine(j) {
+				continue
+			}
+			if !cont[j] && s.indent(j) <= ind {
+				break
+			}
+			last = j
+		}
+
+		// Decorators and comments right above the definition belong to it.
+		first := i
+		for first-1 >= prevEnd {
+			above := strings.TrimSpace(string(s.runes[s.lineStarts[first-1]:s.lineEnd(first-1)]))
+			if s.indent(first-1) != ind || (!strings.HasPrefix(above, "@") && !strings.HasPrefix(above, "#")) {
+				break
+			}
+			first--
+		}
+
+		sym := codeSymbol{
+			start: s.lineStarts[first] + ind,
+			end:   s.lineEnd(last),
+			name:  m[2],
+			kind:  CodeKindFunction,
+		}
+		if m[1] == "class" {
+			sym.kind = CodeKindClass
+			sym.container = true
+			sym.bodyStart = s.lineStarts[min(i+1, len(s.lineStarts)-1)]
+			sym.bodyEnd = sym.end
+		}
+		symbols = append(symbols, sym)
+		prevEnd = last + 1
+		i = j - 1
+	}
+	return symbols
+}
+
+// continuationLines reports, per line, whether it starts inside brackets
+// opened on an earlier line (a multi-line signature or expression).
+func (s *codeSplitter) continuationLines() []bool {
+	if s.continuation != nil {
+		return s.continuation
+	}
+	s.continuation = make([]bool, len(s.lineStarts))
+	depth, line := 0, 0
+	for _, r := range s.masked {
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth = max(depth-1, 0)
+		case '\n':
+			line++
+			s.continuation[line] = depth > 0
+		}
+	}
+	return s.continuation
+}
+
+func (s *codeSplitter) indent(line int) int {
+	n := 0
+	for i := s.lineStarts[line]; i < len(s.runes) && (s.runes[i] == ' ' || s.runes[i] == '\t'); i++ {
+		n++
+	}
+	return n
+}
+
+// blankLine reports whether a line holds nothing but whitespace, comments
+// and string literals (e.g. a docstring line).
+func (s *codeSplitter) blankLine(line int) bool {
+	return strings.TrimSpace(s.maskedLine(line)) == ""
+}
+
+func (s *codeSplitter) maskedLine(line int) string {
+	return string(s.masked[s.lineStarts[line]:s.lineEnd(line)])
+}
+
+// ---------------------------------------------------------------------------
+// Brace languages
+// ---------------------------------------------------------------------------
+
+var (
+	// codeContainerPattern matches a declaration whose body holds members.
+	codeContainerPattern = regexp.MustCompile(
+		`(?:^|[^\w$.])(class|interface|struct|enum|trait|record|object|namespace|module|mod|protocol|extension|union)\s+(?:(?:class|struct)\s+)?([A-Za-z_$][\w$.:]*)`)
+	// rustImplPattern matches "impl<T> Trait for Type" and "impl Type".
+	rustImplPattern = regexp.MustCompile(`(?:^|\s)impl\b(?:\s*<[^{]*?>)?\s+([\w:]+)(?:<[^{]*?>)?(?:\s+for\s+([\w:]+))?`)
+	// codeFunctionKeywordPattern matches "function f", "func (r T) F", "fn f", "fun T.f".
+	codeFunctionKeywordPattern = regexp.MustCompile(
+		`(?:^|[^\w$.])(?:function|func|fn|fun)\b\s*\*?\s*(?:\([^)]*\)\s*)?([A-Za-z_$][\w$.]*)`)
+	// codeArrowPattern matches a function assigned to a name: "const f = (x) =>",
+	// "handler = async function(...)".
+	codeArrowPattern = regexp.MustCompile(
+		`(?:^|[\s;])([A-Za-z_$][\w$]*)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b[^{]*|\([^()]*\)\s*(?::[^=]+)?=>|[A-Za-z_$][\w$]*\s*=>)\s*$`)
+	// codeCallPattern finds "name(" in a signature.
+	codeCallPattern = regexp.MustCompile(`([A-Za-z_$~][\w$:~]*)\s*(?:<[^()]*>)?\s*\(`)
+	// codeSignatureSuffixPattern matches what may follow the parameter list
+	// of a method: return types, qualifiers, throws clauses, initializers.
+	codeSignatureSuffixPattern = regexp.MustCompile(
+		`^\s*(?:(?:const|override|final|noexcept|mutable|async|throws\s+[\w.,\s]+|where\s+.*|->\s*.*|:\s*.*)\s*)*$`)
+	codeAttributePattern = regexp.MustCompile(`\[[^\]]*\]|@[\w.]+(?:\s*\([^)]*\))?`)
+)
+
+// codeControlKeywords are words followed by "(" that do not define a function.
+var codeControlKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "catch": true, "with": true,
+	"synchronized": true, "lock": true, "using": true, "foreach": true, "return": true,
+	"else": true, "do": true, "try": true, "when": true, "match": true, "sizeof": true,
+	"typeof": true, "new": true, "await": true, "yield": true, "unsafe": true, "loop": true,
+	"guard": true, "defer": true, "elseif": true, "fixed": true, "checked": true,
+}
+
+// braceSymbols returns the definitions in runes[from:to] found at brace
+// depth zero relative to from. inType tells that the range is the body of
+// a class-like container, where plain signatures are methods.
+func (s *codeSplitter) braceSymbols(from, to int, inType bool) []codeSymbol {
+	var symbols []codeSymbol
+	stmt := from // the start of the current statement
+	for i := from; i < to; i++ {
+		switch s.masked[i] {
+		case ';', '}':
+			stmt = i + 1
+		case '{':
+			end := s.matchBrace(i, to)
+			declStart := s.declarationStart(stmt, i)
+			if sym, ok := s.classify(declStart, i, end, inType); ok {
+				symbols = append(symbols, sym)
+			}
+			i = end - 1
+			stmt = end
+		}
+	}
+	return symbols
+}
+
+// matchBrace returns the offset just past the brace closing the one at
+// open (and past a ";" right after it), or limit when it is not closed.
+func (s *codeSplitter) matchBrace(open, limit int) int {
+	depth := 0
+	for i := open; i < limit; i++ {
+		switch s.masked[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				end := i + 1
+				if end < limit && s.masked[end] == ';' {
+					end++
+				}
+				return end
+			}
+		}
+	}
+	return limit
+}
+
+// declarationStart returns where the declaration ending at the brace
+// starts: the first line of the last paragraph (no blank line in it)
+// before the brace, doc comments and attributes included.
+func (s *codeSplitter) declarationStart(stmt, brace int) int {
+	start := stmt
+	for i := brace - 1; i > stmt; i-- {
+		if s.runes[i] != '\n' {
+			continue
+		}
+		// A blank line ends the paragraph.
+		j := i - 1
+		for j >= stmt && (s.runes[j] == ' ' || s.runes[j] == '\t' || s.runes[j] == '\r') {
+			j--
+		}
+		if j >= stmt && s.runes[j] == '\n' {
+			start = i + 1
+			break
+		}
+	}
+	for start < brace && unicode.IsSpace(s.runes[start]) {
+		start++
+	}
+	return start
+}
+
+// classify recognizes the declaration masked[declStart:brace] opening the
+// block that ends at end.
+func (s *codeSplitter) classify(declStart, brace, end int, inType bool) (codeSymbol, bool) {
+	header := strings.Join(strings.Fields(string(s.masked[declStart:brace])), " ")
+	header = strings.TrimSpace(codeAttributePattern.ReplaceAllString(header, " "))
+	if header == "" {
+		return codeSymbol{}, false
+	}
+	sym := codeSymbol{start: declStart, end: end, bodyStart: brace + 1, bodyEnd: end - 1}
+
+	if s.language == "rust" {
+		if m := rustImplPattern.FindStringSubmatch(header); m != nil {
+			sym.name, sym.kind, sym.container = m[1], "impl", true
+			if m[2] != "" {
+				sym.name = m[2]
+			}
+			return sym, true
+		}
+	}
+	if loc := codeContainerPattern.FindStringSubmatchIndex(header); loc != nil && !looksLikeFunction(header[loc[1]:]) {
+		sym.kind = header[loc[2]:loc[3]]
+		sym.name = header[loc[4]:loc[5]]
+		if sym.kind == "mod" {
+			sym.kind = "module"
+		}
+		sym.container = true
+		return sym, true
+	}
+	if m := codeFunctionKeywordPattern.FindStringSubmatch(header); m != nil {
+		sym.name, sym.kind = m[1], CodeKindFunction
+		return sym, true
+	}
+	if m := codeArrowPattern.FindStringSubmatch(header); m != nil {
+		sym.name, sym.kind = m[1], CodeKindFunction
+		return sym, true
+	}
+	for _, loc := range codeCallPattern.FindAllStringSubmatchIndex(header, -1) {
+		name := header[loc[2]:loc[3]]
+		if loc[2] > 0 && header[loc[2]-1] == '.' {
+			return codeSymbol{}, false // a call, e.g. "items.forEach(x => {"
+		}
+		if codeControlKeywords[name] || strings.Contains(header[:loc[2]], "=") {
+			return codeSymbol{}, false
+		}
+		params := balancedParens(header[loc[1]-1:])
+		if params < 0 || !codeSignatureSuffixPattern.MatchString(header[loc[1]-1+params:]) {
+			continue
+		}
+		sym.name, sym.kind = name, CodeKindFunction
+		if inType || strings.Contains(name, "::") {
+			sym.kind = CodeKindMethod
+		}
+		return sym, true
+	}
+	return codeSymbol{}, false
+}
+
+// looksLikeFunction tells, from the text following "struct foo" in a C
+// declaration, that the declaration is not the type itself but a function
+// returning it ("struct foo *make(void)") or a variable of it
+// ("struct ops foo_ops = {").
+func looksLikeFunction(rest string) bool {
+	i := strings.IndexByte(rest, '(')
+	if i < 0 {
+		return strings.Contains(rest, "=")
+	}
+	if strings.Contains(rest[:i], "=") {
+		return true
+	}
+	before := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(rest[:i]), "*&"))
+	return before != "" && !strings.ContainsAny(before, " :,<")
+}
+
+// balancedParens returns the length of the parenthesized group at the
+// start of s, or -1 when it is not closed.
+func balancedParens(s string) int {
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return -1
+}
+
+// ---------------------------------------------------------------------------
+// Masking
+// ---------------------------------------------------------------------------
+
+// codeSyntax describes the comments and string literals of a language.
+type codeSyntax struct {
+	lineComments []string
+	blockComment bool
+	quotes       string // characters opening string literals
+	charLiterals bool   // ' opens a character literal (or a Rust lifetime)
+	tripleQuotes bool   // """ (and ''' in Python) opens a multi-line string
+	preprocessor bool   // lines starting with # are directives
+}
+
+var codeSyntaxes = map[string]codeSyntax{
+	"go":         {lineComments: []string{"//"}, blockComment: true, quotes: "\"`", charLiterals: true},
+	"python":     {lineComments: []string{"#"}, quotes: `"'`, tripleQuotes: true},
+	"javascript": {lineComments: []string{"//"}, blockComment: true, quotes: "\"'`"},
+	"typescript": {lineComments: []string{"//"}, blockComment: true, quotes: "\"'`"},
+	"php":        {lineComments: []string{"//", "#"}, blockComment: true, quotes: "\"'`"},
+	"c":          {lineComments: []string{"//"}, blockComment: true, quotes: `"`, charLiterals: true, preprocessor: true},
+	"cpp":        {lineComments: []string{"//"}, blockComment: true, quotes: `"`, charLiterals: true, preprocessor: true},
+	"csharp":     {lineComments: []string{"//"}, blockComment: true, quotes: `"`, charLiterals: true, tripleQuotes: true, preprocessor: true},
+	"kotlin":     {lineComments: []string{"//"}, blockComment: true, quotes: `"`, charLiterals: true, tripleQuotes: true},
+	"scala":      {lineComments: []string{"//"}, blockComment: true, quotes: `"`, charLiterals: true, tripleQuotes: true},
+	"swift":      {lineComments: []string{"//"}, blockComment: true, quotes: `"`, tripleQuotes: true},
+	"java":       {lineComments: []string{"//"}, blockComment: true, quotes: `"`, charLiterals: true, tripleQuotes: true},
+	"rust":       {lineComments: []string{"//"}, blockComment: true, quotes: `"`, charLiterals: true},
+}
+
+// maskCode returns a copy of runes where comments and string literals are
+// replaced by spaces (newlines kept), so that the structure of the code can
+// be scanned without tripping over braces or keywords in them.
+func maskCode(runes []rune, language string) []rune {
+	syntax, ok := codeSyntaxes[language]
+	masked := slices.Clone(runes)
+	if !ok {
+		return masked
+	}
+	blank := func(from, to int) {
+		for i := from; i < to && i < len(masked); i++ {
+			if masked[i] != '\n' {
+				masked[i] = ' '
+			}
+		}
+	}
+	hasPrefix := func(i int, prefix string) bool {
+		for _, r := range prefix {
+			if i >= len(runes) || runes[i] != r {
+				return false
+			}
+			i++
+		}
+		return true
+	}
+	lineEnd := func(i int) int {
+		for i < len(runes) && runes[i] != '\n' {
+			i++
+		}
+		return i
+	}
+
+	atLineStart := true
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if r == '\n' {
+			atLineStart = true
+			i++
+			continue
+		}
+		if atLineStart && syntax.preprocessor && r == '#' {
+			// A directive, continued by trailing backslashes.
+			end := lineEnd(i)
+			for end > 0 && end < len(runes) && runes[end-1] == '\\' {
+				end = lineEnd(end + 1)
+			}
+			blank(i, end)
+			i = end
+			continue
+		}
+		if !unicode.IsSpace(r) {
+			atLineStart = false
+		}
+
+		comment := false
+		for _, lc := range syntax.lineComments {
+			if hasPrefix(i, lc) && !(lc == "#" && hasPrefix(i, "#[")) {
+				end := lineEnd(i)
+				blank(i, end)
+				i, comment = end, true
+				break
+			}
+		}
+		if comment {
+			continue
+		}
+		if syntax.blockComment && hasPrefix(i, "/*") {
+			end := i + 2
+			for end < len(runes) && !hasPrefix(end, "*/") {
+				end++
+			}
+			end = min(end+2, len(runes))
+			blank(i, end)
+			i = end
+			continue
+		}
+		if syntax.tripleQuotes && (hasPrefix(i, `"""`) || (language == "python" && hasPrefix(i, "'''"))) {
+			delim := string(runes[i : i+3])
+			end := i + 3
+			for end < len(runes) && !hasPrefix(end, delim) {
+				if runes[end] == '\\' && language == "python" {
+					end++
+				}
+				end++
+			}
+			end = min(end+3, len(runes))
+			blank(i, end)
+			i = end
+			continue
+		}
+		if language == "rust" && r == 'r' && (i == 0 || !isIdentRune(runes[i-1])) {
+			if end := rustRawStringEnd(runes, i); end > 0 {
+				blank(i, end)
+				i = end
+				continue
+			}
+		}
+		if language == "csharp" && r == '@' && hasPrefix(i+1, `"`) {
+			end := i + 2
+			for end < len(runes) && (runes[end] != '"' || hasPrefix(end, `""`)) {
+				if hasPrefix(end, `""`) {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(runes))
+			blank(i, end)
+			i = end
+			continue
+		}
+		if r == '\'' && syntax.charLiterals {
+			if end := charLiteralEnd(runes, i); end > 0 {
+				blank(i, end)
+				i = end
+			} else {
+				i++ // a Rust lifetime or a stray quote
+			}
+			continue
+		}
+		if strings.ContainsRune(syntax.quotes, r) {
+			multiline := r == '`'
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				if runes[end] == '\\' && !(r == '`' && language == "go") {
+					end++
+				} else if runes[end] == '\n' && !multiline {
+					break // unterminated: end the literal at the line end
+				}
+				end++
+			}
+			end = min(end+1, len(runes))
+			blank(i, end)
+			i = end
+			continue
+		}
+		i++
+	}
+	return masked
+}
+
+// charLiteralEnd returns the offset past the character literal starting at
+// the quote at i ('a', '\n', '\u{1F600}'), or 0 when there is none.
+func charLiteralEnd(runes []rune, i int) int {
+	if i+2 < len(runes) && runes[i+1] != '\\' && runes[i+1] != '\n' && runes[i+2] == '\'' {
+		return i + 3
+	}
+	if i+1 < len(runes) && runes[i+1] == '\\' {
+		for j := i + 3; j < len(runes) && j <= i+12 && runes[j] != '\n'; j++ {
+			if runes[j] == '\'' {
+				return j + 1
+			}
+		}
+	}
+	return 0
+}
+
+// rustRawStringEnd returns the offset past the raw string r"..." or
+// r#"..."# starting at i, or 0 when there is none.
+func rustRawStringEnd(runes []rune, i int) int {
+	j := i + 1
+	hashes := 0
+	for j < len(runes) && runes[j] == '#' {
+		hashes++
+		j++
+	}
+	if j >= len(runes) || runes[j] != '"' {
+		return 0
+	}
+	closing := "\"" + strings.Repeat("#", hashes)
+	for k := j + 1; k+len(closing) <= len(runes); k++ {
+		if string(runes[k:k+len(closing)]) == closing {
+			return k + len(closing)
+		}
+	}
+	return len(runes)
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,249 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+// symbolsOf returns the symbols of the chunks, in order, and checks the
+// chunk invariants on the way.
+func symbolsOf(t *testing.T, text string, chunks []Chunk) []string {
+	t.Helper()
+	runes := []rune(text)
+	var symbols []string
+	for i, c := range chunks {
+		if c.Seq != i {
+			t.Errorf("chunk %d has seq %d", i, c.Seq)
+		}
+		if got := string(runes[c.Start:c.End]); got != c.Content {
+			t.Errorf("chunk %d content does not match its offsets: %q vs %q", i, c.Content, got)
+		}
+		if c.Code == nil {
+			t.Fatalf("chunk %d has no code info", i)
+		}
+		if c.Code.StartLine < 1 || c.Code.EndLine < c.Code.StartLine {
+			t.Errorf("chunk %d has line range %d-%d", i, c.Code.StartLine, c.Code.EndLine)
+		}
+		symbols = append(symbols, c.Code.Symbol+":"+c.Code.Kind)
+	}
+	return symbols
+}
+
+func assertSymbols(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if strings.Join(got, " | ") != strings.Join(want, " | ") {
+		t.Errorf("symbols:\n got %v\nwant %v", got, want)
+	}
+}
+
+func TestSplitCode_Go(t *testing.T) {
+	text := `package server
+
+import "net/http"
+
+// Server serves the API.
+type Server struct {
+	mux *http.ServeMux
+}
+
+// Start starts the server. It logs "{" and "}".
+func (s *Server) Start(addr string) error {
+	return http.ListenAndServe(addr, s.mux)
+}
+
+func NewServer() *Server {
+	return &Server{mux: http.NewServeMux()}
+}
+`
+	chunks := SplitCode(text, "internal/server/server.go", SplitterConfig{ChunkSize: 200})
+	assertSymbols(t, symbolsOf(t, text, chunks),
+		":", "Server:struct", "Server.Start:method", "NewServer:function")
+
+	start := chunks[2]
+	if !strings.HasPrefix(start.Content, "// Start starts the server.") {
+		t.Errorf("doc comment not attached to the method: %q", start.Content)
+	}
+	if start.Code.StartLine != 10 || start.Code.EndLine != 13 {
+		t.Errorf("method lines = %d-%d, want 10-13", start.Code.StartLine, start.Code.EndLine)
+	}
+	if start.ContextHeader != "internal/server/server.go > Server.Start" {
+		t.Errorf("context header = %q", start.ContextHeader)
+	}
+	if start.Code.Language != "go" || start.Code.Path != "internal/server/server.go" {
+		t.Errorf("code info = %+v", start.Code)
+	}
+}
+
+func TestSplitCode_Python(t *testing.T) {
+	text := `import os
+
+
+def helper(path):
+    """Return the path. def not_a_function(): pass"""
+    return os.path.join(
+        path,
+        "x",
+    )
+
+
+# The cache.
+@dataclass
+class Cache:
+    size: int = 0
+
+    def get(self, key):
+        return None
+
+    async def put(self, key, value):
+        pass
+
+
+if __name__ == "__main__":
+    helper(".")
+`
+	chunks := SplitCode(text, "cache.py", SplitterConfig{ChunkSize: 400})
+	assertSymbols(t, symbolsOf(t, text, chunks),
+		":", "helper:function", "Cache:class", ":")
+	if !strings.HasPrefix(chunks[2].Content, "# The cache.\n@dataclass\nclass Cache:") {
+		t.Errorf("comment and decorator not attached to the class: %q", chunks[2].Content)
+	}
+
+	// A class over the size bound is split into its methods.
+	chunks = SplitCode(text, "cache.py", SplitterConfig{ChunkSize: 70})
+	assertSymbols(t, symbolsOf(t, text, chunks),
+		":", "helper:function", "Cache:class", "Cache.get:method", "Cache.put:method", ":")
+}
+
+func TestSplitCode_BraceLanguages(t *testing.T) {
+	tests := []struct {
+		name, path, text string
+		want             []string
+	}{
+		{
+			name: "java",
+			path: "src/Foo.java",
+			text: `package demo;
+
+import java.util.List;
+
+/** Greets. */
+public class Foo extends Base implements Greeter {
+    private final String name = "}";
+
+    @Override
+    public String greet(List<String> names) throws IOException {
+        if (names.isEmpty()) {
+            return "{";
+        }
+        return name;
+    }
+
+    public Foo(String name) {
+        this.name = name;
+    }
+}
+`,
+			want: []string{":", "Foo:class", "Foo.greet:method", "Foo.Foo:method"},
+		},
+		{
+			name: "typescript",
+			path: "web/api.ts",
+			text: "import { get } from './http'\n\n" +
+				"export async function load(id: string): Promise<Item> {\n  return get(`/items/${id}`)\n}\n\n" +
+				"export const save = async (item: Item) => {\n  items.forEach((x) => {\n    x.dirty = false\n  })\n}\n\n" +
+				"export interface Item {\n  id: string\n}\n",
+			want: []string{":", "load:function", "save:function", "Item:interface"},
+		},
+		{
+			name: "c",
+			path: "lib/list.c",
+			text: `#include <stdlib.h>
+#define EMPTY {0}
+
+struct node {
+    int value;
+};
+
+static struct node *node_new(int value) {
+    struct node *n = malloc(sizeof(*n));
+    return n; /* } */
+}
+
+static const struct ops list_ops = {
+    .new = node_new,
+};
+`,
+			want: []string{":", "node:struct", "node_new:function", ":"},
+		},
+		{
+			name: "rust",
+			path: "src/lib.rs",
+			text: `use std::fmt;
+
+/// A point.
+#[derive(Debug)]
+pub struct Point<'a> {
+    label: &'a str,
+}
+
+impl<'a> fmt::Display for Point<'a> {
+    fn fmt(&self, f: &mut fmt::Formatter) -> fmt::Result {
+        write!(f, "{}", self.label)
+    }
+}
+
+pub fn origin() -> char {
+    '{'
+}
+`,
+			want: []string{":", "Point:struct", "Point:impl", "origin:function"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := SplitCode(tt.text, tt.path, SplitterConfig{ChunkSize: 120})
+			assertSymbols(t, symbolsOf(t, tt.text, chunks), tt.want...)
+		})
+	}
+}
+
+func TestSplitCode_LongFunctionSplitByLines(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("package main\n\nfunc main() {\n")
+	for i := 0; i < 40; i++ {
+		b.WriteString("\tprintln(\"line of a long function\")\n")
+	}
+	b.WriteString("}\n")
+	text := b.String()
+
+	chunks := SplitCode(text, "main.go", SplitterConfig{ChunkSize: 200})
+	symbolsOf(t, text, chunks)
+	if len(chunks) < 4 {
+		t.Fatalf("got %d chunks, want the function split by lines", len(chunks))
+	}
+	prevEnd := 0
+	for _, c := range chunks[1:] {
+		if c.Code.Symbol != "main" {
+			t.Errorf("piece of main has symbol %q", c.Code.Symbol)
+		}
+		if len([]rune(c.Content)) > 200 {
+			t.Errorf("piece longer than ChunkSize: %d", len([]rune(c.Content)))
+		}
+		if !strings.HasSuffix(c.Content, ")") && !strings.HasSuffix(c.Content, "}") {
+			t.Errorf("piece not cut at a line end: %q", c.Content)
+		}
+		if c.Code.StartLine <= prevEnd {
+			t.Errorf("pieces overlap: start line %d after end line %d", c.Code.StartLine, prevEnd)
+		}
+		prevEnd = c.Code.EndLine
+	}
+}
+
+func TestSplitCode_UnknownLanguage(t *testing.T) {
+	text := "line one\nline two\n"
+	chunks := SplitCode(text, "notes.txt", SplitterConfig{ChunkSize: 100})
+	assertSymbols(t, symbolsOf(t, text, chunks), ":")
+	if chunks[0].ContextHeader != "notes.txt" {
+		t.Errorf("context header = %q", chunks[0].ContextHeader)
+	}
+}
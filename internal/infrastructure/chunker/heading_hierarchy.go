@@ -77,6 +77,18 @@ func (h *HeadingHierarchy) Breadcrumb() string {
 	return strings.Join(parts, " > ")
 }
 
+// Path returns the active headings, outermost first, or nil when no
+// heading is active. The slice is a copy.
+func (h *HeadingHierarchy) Path() []string {
+	var path []string
+	for i := 0; i < h.depth; i++ {
+		if h.stack[i] != "" {
+			path = append(path, h.stack[i])
+		}
+	}
+	return path
+}
+
 // BreadcrumbWithHashes returns the path with the original `#` prefixes,
 // suitable for embedding back into chunk content as a context header.
 // Example: "# Chapter 1\n## Section 2\n### Subsection a"
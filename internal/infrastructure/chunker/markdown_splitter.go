@@ -0,0 +1,198 @@
+// Package chunker - markdown_splitter.go implements the "markdown" strategy:
+// a structure-first splitter for technical documentation. Every Markdown
+// heading starts a new chunk, each chunk records the full heading path it
+// sits under (HeadingPath, also delivered as ContextHeader in the form
+// "Guide > Install > Linux"), and fenced code blocks are never split — a
+// code block larger than ChunkSize becomes a chunk of its own.
+//
+// Unlike the heading tier (heading_splitter.go), which only cuts at the
+// dominant heading level and falls through to legacy when the validator
+// rejects its output, the markdown strategy is an explicit user choice and
+// its output is always kept.
+package chunker
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// HeadingPathSeparator joins a chunk's HeadingPath into its ContextHeader.
+const HeadingPathSeparator = " > "
+
+// init wires this implementation into the strategy resolver.
+func init() {
+	splitByMarkdown = splitMarkdownImpl
+}
+
+type markdownBlockKind int
+
+const (
+	markdownText markdownBlockKind = iota
+	markdownHeading
+	markdownCode
+)
+
+// markdownBlock is a run of lines: a heading line, a fenced code block
+// (fences included) or a paragraph of non-blank lines. Offsets are runes.
+type markdownBlock struct {
+	kind       markdownBlockKind
+	start, end int
+	line       string // the heading line, for markdownHeading
+}
+
+// splitMarkdownImpl splits text at every heading outside code fences and
+// packs the blocks of each section into chunks of at most ChunkSize runes,
+// without overlap. Paragraphs longer than ChunkSize go through SplitText;
+// code blocks are kept whole whatever their size.
+func splitMarkdownImpl(text string, cfg SplitterConfig) []Chunk {
+	if text == "" {
+		return nil
+	}
+	runes := []rune(text)
+	hierarchy := NewHeadingHierarchy()
+
+	var out []Chunk
+	var path []string
+	// The chunk being packed: [curStart, curEnd), -1 when empty. A section
+	// holding nothing but its heading is carried into the next, deeper
+	// section so that a bare "# Guide" does not become a chunk of its own.
+	curStart, curEnd := -1, -1
+	headingOnly, headingLevel := false, 0
+
+	flush := func() {
+		if curStart < 0 {
+			return
+		}
+		out = append(out, markdownChunk(runes, curStart, curEnd, path))
+		curStart, curEnd, headingOnly = -1, -1, false
+	}
+
+	for _, b := range markdownBlocks(text) {
+		switch b.kind {
+		case markdownHeading:
+			level, _ := hierarchy.Observe(b.line)
+			if !headingOnly || level <= headingLevel {
+				flush()
+			}
+			path = hierarchy.Path()
+			if curStart < 0 {
+				curStart = b.start
+			}
+			curEnd = b.end
+			headingOnly, headingLevel = true, level
+			continue
+		case markdownText:
+			if b.end-b.start > cfg.ChunkSize {
+				// Split the paragraph together with a pending heading.
+				start := b.start
+				if headingOnly {
+					start, curStart, headingOnly = curStart, -1, false
+				}
+				flush()
+				for _, sub := range SplitText(string(runes[start:b.end]), cfg) {
+					out = append(out, markdownChunk(runes, start+sub.Start, start+sub.End, path))
+				}
+				continue
+			}
+		}
+		if curStart >= 0 && b.end-curStart > cfg.ChunkSize && !headingOnly {
+			flush()
+		}
+		if curStart < 0 {
+			curStart = b.start
+		}
+		curEnd = b.end
+		headingOnly = false
+	}
+	flush()
+
+	for i := range out {
+		out[i].Seq = i
+	}
+	return out
+}
+
+// markdownChunk builds the chunk covering runes[start:end] under path.
+func markdownChunk(runes []rune, start, end int, path []string) Chunk {
+	c := Chunk{Content: string(runes[start:end]), Start: start, End: end}
+	if len(path) > 0 {
+		c.HeadingPath = path
+		c.ContextHeader = strings.Join(path, HeadingPathSeparator)
+	}
+	return c
+}
+
+// markdownBlocks cuts text into headings, fenced code blocks and
+// paragraphs, in document order. Blank lines between blocks belong to no
+// block. An unclosed fence runs to the end of the text.
+func markdownBlocks(text string) []markdownBlock {
+	var blocks []markdownBlock
+	var fence string // the opening fence while inside a code block
+	para := -1       // index in blocks of the open paragraph, -1 when none
+	offset := 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		start := offset
+		offset += utf8.RuneCountInString(line)
+		content := strings.TrimRight(line, "\r\n")
+		end := start + utf8.RuneCountInString(content)
+
+		if fence != "" {
+			blocks[len(blocks)-1].end = end
+			if closesFence(content, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if f := openingFence(content); f != "" {
+			fence = f
+			para = -1
+			blocks = append(blocks, markdownBlock{kind: markdownCode, start: start, end: end})
+			continue
+		}
+		if strings.TrimSpace(content) == "" {
+			para = -1
+			continue
+		}
+		if MarkdownHeadingPattern.MatchString(content) {
+			para = -1
+			blocks = append(blocks, markdownBlock{kind: markdownHeading, start: start, end: end, line: content})
+			continue
+		}
+		if para >= 0 {
+			blocks[para].end = end
+			continue
+		}
+		para = len(blocks)
+		blocks = append(blocks, markdownBlock{kind: markdownText, start: start, end: end})
+	}
+	return blocks
+}
+
+// openingFence returns the fence (``` or ~~~, three or more) that line
+// opens, or "" when line is not a fence. Up to three spaces of
+// indentation are allowed, as in CommonMark.
+func openingFence(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) < 3 {
+		return ""
+	}
+	ch := trimmed[0]
+	if ch != '`' && ch != '~' {
+		return ""
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == ch {
+		n++
+	}
+	if n < 3 || (ch == '`' && strings.Contains(trimmed[n:], "`")) {
+		return ""
+	}
+	return trimmed[:n]
+}
+
+// closesFence reports whether line closes a code block opened by fence: the
+// same character, at least as long, and nothing else on the line.
+func closesFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return len(trimmed) >= len(fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
@@ -0,0 +1,105 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitMarkdown_HeadingPaths(t *testing.T) {
+	text := "# Guide\n\nIntro to the guide.\n\n## Install\n\n### Linux\n\nRun the installer.\n\n### macOS\n\nUse brew.\n\n## Usage\n\nStart it."
+
+	chunks := Split(text, SplitterConfig{ChunkSize: 200, ChunkOverlap: 20, Strategy: StrategyMarkdown})
+
+	want := []struct {
+		header  string
+		content string
+	}{
+		{"Guide", "# Guide\n\nIntro to the guide."},
+		// The bare "## Install" heading is carried into its first subsection.
+		{"Guide > Install > Linux", "## Install\n\n### Linux\n\nRun the installer."},
+		{"Guide > Install > macOS", "### macOS\n\nUse brew."},
+		{"Guide > Usage", "## Usage\n\nStart it."},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected %d chunks, got %d: %+v", len(want), len(chunks), chunks)
+	}
+	runes := []rune(text)
+	for i, w := range want {
+		c := chunks[i]
+		if c.ContextHeader != w.header || c.Content != w.content {
+			t.Errorf("chunk %d = (%q, %q), want (%q, %q)", i, c.ContextHeader, c.Content, w.header, w.content)
+		}
+		if strings.Join(c.HeadingPath, HeadingPathSeparator) != w.header {
+			t.Errorf("chunk %d heading path %v", i, c.HeadingPath)
+		}
+		if string(runes[c.Start:c.End]) != c.Content || c.Seq != i {
+			t.Errorf("chunk %d offsets or seq do not match its content", i)
+		}
+	}
+	if got := chunks[1].EmbeddingContent(); !strings.HasPrefix(got, "Guide > Install > Linux\n\n") {
+		t.Errorf("embedding content lacks the heading path: %q", got)
+	}
+}
+
+func TestSplitMarkdown_NeverSplitsCodeBlocks(t *testing.T) {
+	code := "```go\n" + strings.Repeat("fmt.Println(\"hello\")\n", 40) + "\n# not a heading\n```"
+	text := "## Example\n\nSome text before the code.\n\n" + code + "\n\nText after."
+
+	chunks := Split(text, SplitterConfig{ChunkSize: 100, ChunkOverlap: 0, Strategy: StrategyMarkdown})
+
+	var found bool
+	for _, c := range chunks {
+		if strings.Contains(c.Content, "```") {
+			if !strings.Contains(c.Content, code) {
+				t.Fatalf("code block was split: %q", c.Content)
+			}
+			found = true
+		}
+		if c.ContextHeader != "Example" {
+			t.Errorf("heading inside the code block was treated as a heading: %q", c.ContextHeader)
+		}
+	}
+	if !found {
+		t.Fatal("code block missing from the chunks")
+	}
+}
+
+func TestSplitMarkdown_PacksAndSplitsLongParagraphs(t *testing.T) {
+	long := strings.Repeat("This sentence is part of a long paragraph. ", 10)
+	text := "# Notes\n\nshort one.\n\nshort two.\n\n" + long
+
+	chunks := Split(text, SplitterConfig{
+		ChunkSize: 120, ChunkOverlap: 0, Separators: []string{"\n\n", "\n", ". "}, Strategy: StrategyMarkdown,
+	})
+
+	if chunks[0].Content != "# Notes\n\nshort one.\n\nshort two." {
+		t.Errorf("short paragraphs were not packed: %q", chunks[0].Content)
+	}
+	if len(chunks) < 3 {
+		t.Fatalf("long paragraph was not split: %d chunks", len(chunks))
+	}
+	for _, c := range chunks {
+		if n := len([]rune(c.Content)); n > 120 {
+			t.Errorf("chunk of %d runes exceeds the chunk size", n)
+		}
+		if c.ContextHeader != "Notes" {
+			t.Errorf("unexpected context header %q", c.ContextHeader)
+		}
+	}
+}
+
+func TestSplitParentChild_MergesHeadingPaths(t *testing.T) {
+	text := "# Guide\n\n## Install\n\n" + strings.Repeat("Install step text. ", 30)
+	res := SplitParentChild(text,
+		SplitterConfig{ChunkSize: 2000, Strategy: StrategyMarkdown},
+		SplitterConfig{ChunkSize: 200, ChunkOverlap: 0, Strategy: StrategyMarkdown})
+
+	if len(res.Children) < 2 {
+		t.Fatalf("expected the parent to be split, got %d children", len(res.Children))
+	}
+	for _, c := range res.Children {
+		if c.ContextHeader != "Guide > Install" {
+			t.Errorf("child heading path %v (%q)", c.HeadingPath, c.ContextHeader)
+		}
+	}
+}
@@ -211,6 +211,7 @@ const (
 	TierHeading   StrategyTier = "heading"
 	TierHeuristic StrategyTier = "heuristic"
 	TierLegacy    StrategyTier = "legacy"
+	TierMarkdown  StrategyTier = "markdown"
 )
 
 // SelectStrategy returns the ordered tier chain to attempt for this document.
@@ -29,6 +29,13 @@ type Chunk struct {
 	Seq           int
 	Start         int
 	End           int
+	// HeadingPath lists the headings the chunk sits under, outermost
+	// first. Set by the markdown strategy only.
+	HeadingPath []string
+	// Table is set on chunks cut from a table by SplitWithTables.
+	Table *TableInfo
+	// Code is set on chunks of source files cut by SplitCode.
+	Code *CodeInfo
 }
 
 // EmbeddingContent returns the text that should be fed to the embedding
@@ -9,6 +9,7 @@ package chunker
 
 import (
 	"context"
+	"slices"
 	"strings"
 
 	"github.com/Tencent/WeKnora/internal/logger"
@@ -21,12 +22,17 @@ const (
 	StrategyHeuristic = "heuristic"
 	StrategyRecursive = "recursive"
 	StrategyLegacy    = "legacy"
+	// StrategyMarkdown splits at every heading, records the heading path of
+	// each chunk and never splits code blocks. See markdown_splitter.go.
+	StrategyMarkdown = "markdown"
 )
 
 // Split chunks text using the strategy configured in cfg. When cfg.Strategy
 // is empty or "auto" the document profiler picks the tier. The function
 // always returns a non-nil result: on tier failure the chain falls through
-// to the legacy splitter, which is the original Tier 3 implementation.
+// to the legacy splitter, which is the original Tier 3 implementation. The
+// output of the last tier of the chain is kept even when the validator
+// rejects it.
 //
 // Hot path: avoids the Diagnostics struct allocation that
 // SplitWithDiagnostics performs (matters in SplitParentChild where
@@ -47,7 +53,7 @@ func Split(text string, cfg SplitterConfig) []Chunk {
 		} else {
 			logger.Debugf(context.Background(), "chunker: tier %s rejected: %s", tier, v.Reason)
 		}
-		if tier == TierLegacy && i == len(chain)-1 {
+		if i == len(chain)-1 {
 			lastOut = out
 		}
 	}
@@ -109,7 +115,7 @@ func SplitWithDiagnostics(text string, cfg SplitterConfig) ([]Chunk, *Diagnostic
 		}
 		diag.Rejected = append(diag.Rejected, TierRejection{Tier: tier, Reason: v.Reason})
 		logger.Debugf(context.Background(), "chunker: tier %s rejected: %s", tier, v.Reason)
-		if tier == TierLegacy && i == len(chain)-1 {
+		if i == len(chain)-1 {
 			lastOut = out
 			lastTier = tier
 		}
@@ -159,7 +165,12 @@ func SplitParentChild(text string, parentCfg, childCfg SplitterConfig) ParentChi
 			sub.Seq = childSeq
 			sub.Start += parent.Start
 			sub.End += parent.Start
-			sub.ContextHeader = mergeBreadcrumbs(parent.ContextHeader, sub.ContextHeader)
+			if parent.HeadingPath != nil || sub.HeadingPath != nil {
+				sub.HeadingPath = mergeHeadingPaths(parent.HeadingPath, sub.HeadingPath)
+				sub.ContextHeader = strings.Join(sub.HeadingPath, HeadingPathSeparator)
+			} else {
+				sub.ContextHeader = mergeBreadcrumbs(parent.ContextHeader, sub.ContextHeader)
+			}
 			children = append(children, ChildChunk{Chunk: sub, ParentIndex: parentIndex})
 			childSeq++
 		}
@@ -191,6 +202,19 @@ func mergeBreadcrumbs(parent, child string) string {
 	return parent + "\n" + strings.Join(childLines, "\n")
 }
 
+// mergeHeadingPaths is the HeadingPath analog of mergeBreadcrumbs. The
+// child path starts at the headings that open the parent content, which end
+// the parent path; the longest such overlap is dropped from the child.
+func mergeHeadingPaths(parent, child []string) []string {
+	for n := min(len(parent), len(child)); n > 0; n-- {
+		if slices.Equal(parent[len(parent)-n:], child[:n]) {
+			child = child[n:]
+			break
+		}
+	}
+	return append(append([]string(nil), parent...), child...)
+}
+
 // resolveChainWithProfile returns the strategy chain to attempt and, when
 // the chain was selected by the profiler (auto strategy), the DocProfile
 // that drove the selection. Profile is nil for explicit non-auto strategies
@@ -201,6 +225,8 @@ func resolveChainWithProfile(text string, cfg SplitterConfig) ([]StrategyTier, *
 		return []StrategyTier{TierHeading, TierLegacy}, nil
 	case StrategyHeuristic:
 		return []StrategyTier{TierHeuristic, TierLegacy}, nil
+	case StrategyMarkdown:
+		return []StrategyTier{TierMarkdown}, nil
 	case StrategyRecursive:
 		// "recursive" is a public-API alias for "legacy": both invoke
 		// SplitText. Kept for backwards compatibility with stored configs.
@@ -232,6 +258,8 @@ func runTier(tier StrategyTier, text string, cfg SplitterConfig, profile *DocPro
 		return splitByHeadings(text, cfg, profile)
 	case TierHeuristic:
 		return splitByHeuristics(text, cfg, profile)
+	case TierMarkdown:
+		return splitByMarkdown(text, cfg)
 	case TierLegacy:
 		return SplitText(text, cfg)
 	}
@@ -286,3 +314,8 @@ var splitByHeadings = func(text string, cfg SplitterConfig, _ *DocProfile) []Chu
 var splitByHeuristics = func(text string, cfg SplitterConfig, _ *DocProfile) []Chunk {
 	return SplitText(text, cfg)
 }
+
+// splitByMarkdown is overridden by markdown_splitter.go.
+var splitByMarkdown = func(text string, cfg SplitterConfig) []Chunk {
+	return SplitText(text, cfg)
+}
@@ -0,0 +1,242 @@
+// Package chunker - table.go implements table-aware chunking: Markdown and
+// HTML tables are cut out of the document and kept as atomic chunks that
+// carry their column names and row range, while the text around them goes
+// through the regular Split strategy.
+package chunker
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// tableChunkSizeFactor bounds a table chunk at this many times ChunkSize.
+// Larger Markdown tables are cut on row boundaries; each continuation piece
+// carries the table header in its ContextHeader.
+const tableChunkSizeFactor = 4
+
+var (
+	tableSeparatorLinePattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)+\|?\s*$`)
+	htmlTablePattern          = regexp.MustCompile(`(?is)<table\b[^>]*>.*?</table>`)
+	htmlRowPattern            = regexp.MustCompile(`(?is)<tr\b[^>]*>(.*?)</tr>`)
+	htmlHeaderCellPattern     = regexp.MustCompile(`(?is)<th\b[^>]*>(.*?)</th>`)
+	htmlTagPattern            = regexp.MustCompile(`<[^>]+>`)
+	headingLinePattern        = regexp.MustCompile(`(?m)^#{1,6}[ \t]+\S.*$`)
+)
+
+// TableInfo describes the table a chunk was cut from.
+type TableInfo struct {
+	Index    int      // 0-based position of the table in the document
+	Columns  []string // header cells; empty when the header is not known
+	RowCount int      // data rows of the whole table
+	RowStart int      // first data row in this chunk, 1-based; 0 when the chunk holds no data row
+	RowEnd   int      // last data row in this chunk
+}
+
+// table is a table found in the document. Start/End are rune offsets.
+type table struct {
+	start, end int
+	html       bool
+	header     []string
+	// lines are the rune ranges of the header, separator and data rows of
+	// a Markdown table.
+	lines [][2]int
+}
+
+// SplitWithTables splits text like Split, except that every table becomes
+// a chunk of its own, with Table set. A table is never merged with the
+// surrounding text; a Markdown table longer than tableChunkSizeFactor times
+// ChunkSize is cut between rows.
+func SplitWithTables(text string, cfg SplitterConfig) []Chunk {
+	tables := findTables(text)
+	if len(tables) == 0 {
+		return Split(text, cfg)
+	}
+	cfg = ensureDefaults(cfg)
+	runes := []rune(text)
+
+	var out []Chunk
+	splitText := func(start, end int) {
+		segment := string(runes[start:end])
+		if strings.TrimSpace(segment) == "" {
+			return
+		}
+		for _, c := range Split(segment, cfg) {
+			c.Start += start
+			c.End += start
+			out = append(out, c)
+		}
+	}
+
+	pos := 0
+	for i, t := range tables {
+		splitText(pos, t.start)
+		heading := lastHeadingBefore(string(runes[:t.start]))
+		out = append(out, t.chunks(runes, i, cfg.ChunkSize*tableChunkSizeFactor, heading)...)
+		pos = t.end
+	}
+	splitText(pos, len(runes))
+
+	for i := range out {
+		out[i].Seq = i
+	}
+	return out
+}
+
+// findTables returns the Markdown and HTML tables of text in document order.
+func findTables(text string) []table {
+	var tables []table
+
+	// HTML tables first: Markdown-looking lines inside them are not tables.
+	for _, loc := range htmlTablePattern.FindAllStringIndex(text, -1) {
+		block := text[loc[0]:loc[1]]
+		t := table{
+			start: utf8.RuneCountInString(text[:loc[0]]),
+			html:  true,
+		}
+		t.end = t.start + utf8.RuneCountInString(block)
+		for _, m := range htmlHeaderCellPattern.FindAllStringSubmatch(block, -1) {
+			t.header = append(t.header, strings.TrimSpace(htmlTagPattern.ReplaceAllString(m[1], "")))
+		}
+		tables = append(tables, t)
+	}
+	inHTML := func(offset int) bool {
+		for _, t := range tables {
+			if t.html && offset >= t.start && offset < t.end {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Markdown tables: a header row, a separator row, then the data rows.
+	type line struct {
+		start, end int
+		text       string
+	}
+	var lines []line
+	offset := 0
+	for _, l := range strings.Split(text, "\n") {
+		n := utf8.RuneCountInString(l)
+		lines = append(lines, line{start: offset, end: offset + n, text: l})
+		offset += n + 1
+	}
+	var markdown []table
+	for i := 0; i+1 < len(lines); i++ {
+		if !strings.Contains(lines[i].text, "|") || !tableSeparatorLinePattern.MatchString(lines[i+1].text) ||
+			inHTML(lines[i].start) {
+			continue
+		}
+		t := table{start: lines[i].start, header: splitTableRow(lines[i].text)}
+		j := i
+		for ; j < len(lines); j++ {
+			if j > i+1 && (strings.TrimSpace(lines[j].text) == "" || !strings.Contains(lines[j].text, "|")) {
+				break
+			}
+			end := lines[j].end
+			if strings.HasSuffix(lines[j].text, "\r") {
+				end--
+			}
+			t.lines = append(t.lines, [2]int{lines[j].start, end})
+		}
+		t.end = t.lines[len(t.lines)-1][1]
+		markdown = append(markdown, t)
+		i = j - 1
+	}
+
+	return mergeTables(tables, markdown)
+}
+
+// mergeTables merges two lists of tables sorted by start offset.
+func mergeTables(a, b []table) []table {
+	out := make([]table, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if a[0].start < b[0].start {
+			out, a = append(out, a[0]), a[1:]
+		} else {
+			out, b = append(out, b[0]), b[1:]
+		}
+	}
+	return append(append(out, a...), b...)
+}
+
+// splitTableRow returns the trimmed cells of a Markdown table row.
+func splitTableRow(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	row = strings.TrimSuffix(row, "|")
+	cells := strings.Split(row, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// lastHeadingBefore returns the last Markdown heading line of text.
+func lastHeadingBefore(text string) string {
+	headings := headingLinePattern.FindAllString(text, -1)
+	if len(headings) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(headings[len(headings)-1])
+}
+
+// chunks cuts the table into chunks of at most maxRunes runes (a single row
+// longer than that still forms a chunk). ContextHeader holds the heading
+// the table belongs to, and for continuation pieces the table header rows.
+func (t table) chunks(runes []rune, index, maxRunes int, heading string) []Chunk {
+	info := TableInfo{Index: index, Columns: t.header}
+	if t.html {
+		block := string(runes[t.start:t.end])
+		for _, m := range htmlRowPattern.FindAllStringSubmatch(block, -1) {
+			if !htmlHeaderCellPattern.MatchString(m[1]) {
+				info.RowCount++
+			}
+		}
+		if info.RowCount > 0 {
+			info.RowStart, info.RowEnd = 1, info.RowCount
+		}
+		return []Chunk{{Content: block, ContextHeader: heading, Start: t.start, End: t.end, Table: &info}}
+	}
+
+	info.RowCount = len(t.lines) - 2
+	headerEnd := t.lines[1][1]
+	if t.end-t.start <= maxRunes || info.RowCount <= 1 {
+		if info.RowCount > 0 {
+			info.RowStart, info.RowEnd = 1, info.RowCount
+		}
+		return []Chunk{{
+			Content: string(runes[t.start:t.end]), ContextHeader: heading,
+			Start: t.start, End: t.end, Table: &info,
+		}}
+	}
+
+	continuationHeader := string(runes[t.start:headerEnd])
+	if heading != "" {
+		continuationHeader = heading + "\n\n" + continuationHeader
+	}
+	var out []Chunk
+	pieceStart, firstRow := t.start, 1
+	for row := 1; row <= info.RowCount; row++ {
+		line := t.lines[row+1]
+		last := row == info.RowCount
+		// Close the piece before this row when the row would overflow it.
+		if row > firstRow && line[1]-pieceStart > maxRunes {
+			out = append(out, t.piece(runes, info, pieceStart, t.lines[row][1], firstRow, row-1, heading, continuationHeader))
+			pieceStart, firstRow = line[0], row
+		}
+		if last {
+			out = append(out, t.piece(runes, info, pieceStart, line[1], firstRow, row, heading, continuationHeader))
+		}
+	}
+	return out
+}
+
+func (t table) piece(runes []rune, info TableInfo, start, end, rowStart, rowEnd int, heading, continuationHeader string) Chunk {
+	info.RowStart, info.RowEnd = rowStart, rowEnd
+	header := heading
+	if start != t.start {
+		header = continuationHeader
+	}
+	return Chunk{Content: string(runes[start:end]), ContextHeader: header, Start: start, End: end, Table: &info}
+}
@@ -0,0 +1,97 @@
+package chunker
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitWithTables_KeepsTableAtomic(t *testing.T) {
+	intro := "# 销售报告\n\n" + strings.Repeat("本季度销售额稳步增长。", 8)
+	tbl := "| 地区 | 销售额 | 增长 |\n| --- | ---: | --- |\n| 华东 | 120 | 5% |\n| 华南 | 98 | 3% |"
+	outro := "## 结论\n\n整体表现良好。"
+	text := intro + "\n\n" + tbl + "\n\n" + outro
+
+	chunks := SplitWithTables(text, SplitterConfig{ChunkSize: 60, ChunkOverlap: 0})
+
+	var tables []Chunk
+	for i, c := range chunks {
+		if c.Seq != i {
+			t.Errorf("chunk %d has seq %d", i, c.Seq)
+		}
+		if got := string([]rune(text)[c.Start:c.End]); got != c.Content {
+			t.Errorf("chunk %d content does not match its offsets: %q vs %q", i, c.Content, got)
+		}
+		if c.Table != nil {
+			tables = append(tables, c)
+		} else if strings.Contains(c.Content, "|") {
+			t.Errorf("table text leaked into chunk %q", c.Content)
+		}
+	}
+	if len(tables) != 1 {
+		t.Fatalf("expected one table chunk, got %d", len(tables))
+	}
+	tc := tables[0]
+	if tc.Content != tbl || tc.ContextHeader != "# 销售报告" {
+		t.Errorf("unexpected table chunk %q (header %q)", tc.Content, tc.ContextHeader)
+	}
+	info := tc.Table
+	if info.Index != 0 || info.RowCount != 2 || info.RowStart != 1 || info.RowEnd != 2 ||
+		strings.Join(info.Columns, ",") != "地区,销售额,增长" {
+		t.Errorf("unexpected table info %+v", info)
+	}
+}
+
+func TestSplitWithTables_SplitsLargeTableOnRows(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("| id | name |\n|---|---|\n")
+	for i := 1; i <= 30; i++ {
+		fmt.Fprintf(&b, "| %d | item-%02d |\n", i, i)
+	}
+	text := "intro\n\n" + strings.TrimSuffix(b.String(), "\n")
+
+	chunks := SplitWithTables(text, SplitterConfig{ChunkSize: 50, ChunkOverlap: 0})
+
+	nextRow := 1
+	for _, c := range chunks {
+		if c.Table == nil {
+			continue
+		}
+		if utf8.RuneCountInString(c.Content) > 50*tableChunkSizeFactor {
+			t.Errorf("table piece too long: %d runes", utf8.RuneCountInString(c.Content))
+		}
+		if c.Table.RowStart != nextRow || c.Table.RowCount != 30 {
+			t.Errorf("unexpected row range %+v, want start %d", c.Table, nextRow)
+		}
+		if c.Table.RowStart > 1 && !strings.HasPrefix(c.ContextHeader, "| id | name |") {
+			t.Errorf("continuation piece lacks the table header: %q", c.ContextHeader)
+		}
+		nextRow = c.Table.RowEnd + 1
+	}
+	if nextRow != 31 {
+		t.Fatalf("rows not fully covered, next row %d", nextRow)
+	}
+}
+
+func TestSplitWithTables_HTMLTable(t *testing.T) {
+	html := `<table><tr><th>A</th><th>B</th></tr><tr><td rowspan="2">1</td><td>2</td></tr><tr><td>3</td></tr></table>`
+	text := "before\n\n" + html + "\n\nafter"
+
+	chunks := SplitWithTables(text, SplitterConfig{ChunkSize: 20, ChunkOverlap: 0})
+	if len(chunks) != 3 || chunks[1].Content != html {
+		t.Fatalf("unexpected chunks %+v", chunks)
+	}
+	if info := chunks[1].Table; info.RowCount != 2 || strings.Join(info.Columns, ",") != "A,B" {
+		t.Errorf("unexpected table info %+v", info)
+	}
+}
+
+func TestSplitWithTables_NoTables(t *testing.T) {
+	text := "plain | text without a separator row\n\nmore text"
+	cfg := SplitterConfig{ChunkSize: 20, ChunkOverlap: 0}
+	got, want := SplitWithTables(text, cfg), Split(text, cfg)
+	if len(got) != len(want) {
+		t.Fatalf("expected the regular split, got %d chunks, want %d", len(got), len(want))
+	}
+}
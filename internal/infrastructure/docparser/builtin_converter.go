@@ -28,6 +28,12 @@ var audioFormats = map[string]bool{
 	"mp3": true, "wav": true, "m4a": true, "flac": true, "ogg": true,
 }
 
+// transcribableVideoFormats are video containers accepted as-is by
+// Whisper-compatible ASR endpoints; their audio track is transcribed.
+var transcribableVideoFormats = map[string]bool{
+	"mp4": true, "webm": true,
+}
+
 func init() {
 	for k := range imageFormats {
 		simpleFormats[k] = true
@@ -35,6 +41,9 @@ func init() {
 	for k := range audioFormats {
 		simpleFormats[k] = true
 	}
+	for k := range transcribableVideoFormats {
+		simpleFormats[k] = true
+	}
 }
 
 // IsSimpleFormat returns true if the file type can be handled by the Go SimpleFormatReader.
@@ -72,8 +81,11 @@ func (b *SimpleFormatReader) Read(_ context.Context, req *types.ReadRequest) (*t
 		return &types.ReadResult{MarkdownContent: md}, nil
 	case imageFormats[ft]:
 		return imageToResult(req.FileName, req.FileContent), nil
-	case audioFormats[ft]:
+	case audioFormats[ft], transcribableVideoFormats[ft]:
 		return audioToResult(req.FileName, req.FileContent), nil
+	case codeLanguages[ft] != "":
+		// Source code is kept verbatim; it is split by symbol, not as markdown.
+		return &types.ReadResult{MarkdownContent: string(req.FileContent)}, nil
 	default:
 		return nil, fmt.Errorf("unsupported simple format: %s", ft)
 	}
@@ -0,0 +1,97 @@
+package docparser
+
+import (
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// codeLanguages maps the extensions of the source files accepted for
+// ingestion to the language recorded in the metadata of their chunks.
+// Source files are read as plain text and split by symbol
+// (chunker.SplitCode).
+var codeLanguages = map[string]string{
+	"go":    "go",
+	"py":    "python",
+	"pyi":   "python",
+	"js":    "javascript",
+	"jsx":   "javascript",
+	"mjs":   "javascript",
+	"cjs":   "javascript",
+	"ts":    "typescript",
+	"tsx":   "typescript",
+	"java":  "java",
+	"kt":    "kotlin",
+	"kts":   "kotlin",
+	"scala": "scala",
+	"swift": "swift",
+	"c":     "c",
+	"h":     "c",
+	"cc":    "cpp",
+	"cpp":   "cpp",
+	"cxx":   "cpp",
+	"hh":    "cpp",
+	"hpp":   "cpp",
+	"hxx":   "cpp",
+	"cs":    "csharp",
+	"rs":    "rust",
+	"php":   "php",
+}
+
+func init() {
+	for k := range codeLanguages {
+		simpleFormats[k] = true
+	}
+}
+
+// CodeLanguage returns the programming language of a source file, given
+// its file type ("go", ".py") or file name ("cmd/main.go"), or "" when it
+// is not a supported source file.
+func CodeLanguage(fileTypeOrName string) string {
+	ft := strings.ToLower(fileTypeOrName)
+	if ext := filepath.Ext(ft); ext != "" {
+		ft = ext
+	}
+	return codeLanguages[strings.TrimPrefix(ft, ".")]
+}
+
+// repositorySkippedDirs are directories of dependencies and build output
+// whose files are not ingested from a code repository.
+var repositorySkippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+	"third_party":  true,
+	"dist":         true,
+	"build":        true,
+	"target":       true,
+	"__pycache__":  true,
+	"venv":         true,
+}
+
+// IsRepositoryFile reports whether the file at path (slash-separated,
+// relative to the repository root) is ingested from a code repository:
+// source files and Markdown documents, outside hidden directories and the
+// directories of dependencies and build output.
+func IsRepositoryFile(path string) bool {
+	parts := strings.Split(path, "/")
+	for _, part := range parts {
+		if part == "" || part == "." || part == ".." || strings.HasPrefix(part, ".") || repositorySkippedDirs[part] {
+			return false
+		}
+	}
+	if CodeLanguage(path) != "" {
+		return true
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return true
+	}
+	return false
+}
+
+// CodeFileTypes returns the extensions of the supported source files,
+// sorted.
+func CodeFileTypes() []string {
+	return slices.Sorted(maps.Keys(codeLanguages))
+}
@@ -0,0 +1,45 @@
+package docparser
+
+import "testing"
+
+func TestCodeLanguage(t *testing.T) {
+	tests := map[string]string{
+		"go":                "go",
+		".PY":               "python",
+		"internal/app.tsx":  "typescript",
+		"include/list.h":    "c",
+		"src/lib.rs":        "rust",
+		"README.md":         "",
+		"Makefile":          "",
+		"archive.tar.gz":    "",
+		"Service.Test.java": "java",
+	}
+	for input, want := range tests {
+		if got := CodeLanguage(input); got != want {
+			t.Errorf("CodeLanguage(%q) = %q, want %q", input, got, want)
+		}
+	}
+	if !IsSimpleFormat("kt") {
+		t.Error("source files should be read by the simple format reader")
+	}
+}
+
+func TestIsRepositoryFile(t *testing.T) {
+	for path, want := range map[string]bool{
+		"main.go":                     true,
+		"internal/server/server.go":   true,
+		"docs/Guide.MD":               true,
+		"web/src/App.tsx":             true,
+		"LICENSE":                     false,
+		"assets/logo.png":             false,
+		".github/workflows/ci.go":     false,
+		"web/node_modules/x/index.js": false,
+		"vendor/lib/lib.go":           false,
+		"../outside.go":               false,
+		"a//b.go":                     false,
+	} {
+		if got := IsRepositoryFile(path); got != want {
+			t.Errorf("IsRepositoryFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package docparser
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// OCR providers selected by the "ocr_provider" override.
+const (
+	// OCRProviderPaddleOCR calls the /layout-parsing API of a PaddleOCR
+	// PP-StructureV3 pipeline service.
+	OCRProviderPaddleOCR = "paddleocr"
+	// OCRProviderHTTP calls a custom OCR service, see ocrHTTPResponse.
+	OCRProviderHTTP = "http"
+)
+
+const (
+	ocrTimeout = 1000 * time.Second // same budget as PaddleOCR-VL, scanned PDFs are slow
+	// minExtractableTextRunes is the amount of text (image references and
+	// whitespace excluded) below which a PDF is treated as scanned.
+	minExtractableTextRunes = 20
+)
+
+// OCRClient recognizes the text blocks of scanned PDFs and images, in
+// reading order and with their bounding boxes. It is the fallback used when
+// the document parser finds no text layer.
+type OCRClient struct {
+	endpoint string
+	provider string
+	client   *http.Client
+}
+
+// NewOCRClient creates an OCR client from ParserEngineOverrides
+// ("ocr_endpoint", "ocr_provider"). It returns nil when no OCR service is
+// configured.
+func NewOCRClient(overrides map[string]string) *OCRClient {
+	endpoint := strings.TrimRight(strings.TrimSpace(overrides["ocr_endpoint"]), "/")
+	if endpoint == "" {
+		return nil
+	}
+	return &OCRClient{
+		endpoint: endpoint,
+		provider: strings.ToLower(stringOr(overrides["ocr_provider"], OCRProviderPaddleOCR)),
+		client:   &http.Client{Timeout: ocrTimeout},
+	}
+}
+
+// NeedsOCR reports whether a parsed document should be run through OCR: a
+// PDF without extractable text, or an image that is not described by the
+// multimodal (VLM) pipeline.
+func NeedsOCR(fileType string, result *types.ReadResult, multimodal bool) bool {
+	if result == nil || result.IsAudio || len(result.LayoutBlocks) > 0 {
+		return false
+	}
+	ft := strings.ToLower(strings.TrimPrefix(fileType, "."))
+	switch {
+	case ft == "pdf":
+		return extractableTextRunes(result.MarkdownContent) < minExtractableTextRunes
+	case imageFormats[ft]:
+		return !multimodal
+	default:
+		return false
+	}
+}
+
+var markdownImagePattern = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)|<img[^>]*>`)
+
+// extractableTextRunes counts the non-blank runes of markdown, image
+// references excluded.
+func extractableTextRunes(markdown string) int {
+	n := 0
+	for _, r := range markdownImagePattern.ReplaceAllString(markdown, "") {
+		if !unicode.IsSpace(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// Recognize sends the file to the OCR service and returns its text blocks in
+// reading order. Start and End of the blocks are left for ApplyOCR to fill.
+func (c *OCRClient) Recognize(ctx context.Context, req *types.ReadRequest) ([]types.LayoutBlock, error) {
+	if len(req.FileContent) == 0 {
+		return nil, fmt.Errorf("no file content provided")
+	}
+	logger.Infof(ctx, "[OCR] Recognizing file=%s size=%d via %s (%s)",
+		req.FileName, len(req.FileContent), c.endpoint, c.provider)
+
+	file := base64.StdEncoding.EncodeToString(req.FileContent)
+	switch c.provider {
+	case OCRProviderPaddleOCR:
+		var resp paddleOCRLayoutResponse
+		err := c.post(ctx, c.endpoint+"/layout-parsing", map[string]interface{}{
+			"file":      file,
+			"fileType":  fileTypeCode(req),
+			"visualize": false,
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		if resp.ErrorCode != 0 {
+			return nil, fmt.Errorf("PaddleOCR error %d: %s", resp.ErrorCode, resp.ErrorMsg)
+		}
+		return resp.blocks(), nil
+	case OCRProviderHTTP:
+		var resp ocrHTTPResponse
+		err := c.post(ctx, c.endpoint, map[string]interface{}{
+			"file":      file,
+			"file_name": req.FileName,
+			"file_type": req.FileType,
+		}, &resp)
+		if err != nil {
+			return nil, err
+		}
+		return resp.blocks(), nil
+	default:
+		return nil, fmt.Errorf("unsupported OCR provider %q", c.provider)
+	}
+}
+
+func (c *OCRClient) post(ctx context.Context, url string, payload interface{}, out interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("OCR API status %d: %s", resp.StatusCode, string(respBody))
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// paddleOCRLayoutResponse mirrors the fields of the PP-StructureV3
+// /layout-parsing response used for layout: one entry per page, whose
+// parsing_res_list holds the blocks in reading order.
+type paddleOCRLayoutResponse struct {
+	ErrorCode int    `json:"errorCode"`
+	ErrorMsg  string `json:"errorMsg"`
+	Result    struct {
+		LayoutParsingResults []struct {
+			PrunedResult struct {
+				Width          float64 `json:"width"`
+				Height         float64 `json:"height"`
+				ParsingResList []struct {
+					Label   string     `json:"block_label"`
+					Content string     `json:"block_content"`
+					BBox    [4]float64 `json:"block_bbox"`
+				} `json:"parsing_res_list"`
+			} `json:"prunedResult"`
+		} `json:"layoutParsingResults"`
+	} `json:"result"`
+}
+
+func (r *paddleOCRLayoutResponse) blocks() []types.LayoutBlock {
+	var blocks []types.LayoutBlock
+	for i, page := range r.Result.LayoutParsingResults {
+		for _, b := range page.PrunedResult.ParsingResList {
+			blocks = append(blocks, types.LayoutBlock{
+				LayoutRegion: types.LayoutRegion{
+					Page:       i + 1,
+					BBox:       b.BBox,
+					PageWidth:  page.PrunedResult.Width,
+					PageHeight: page.PrunedResult.Height,
+				},
+				Label: b.Label,
+				Text:  b.Content,
+			})
+		}
+	}
+	return blocks
+}
+
+// ocrHTTPResponse is the response of a custom OCR service (provider "http").
+// The service receives {"file": <base64>, "file_name", "file_type"} and
+// answers with the blocks of every page in reading order:
+//
+//	{"pages": [{"page": 1, "width": 1240, "height": 1754,
+//	            "blocks": [{"text": "...", "label": "title", "bbox": [x0, y0, x1, y1]}]}]}
+type ocrHTTPResponse struct {
+	Pages []struct {
+		Page   int     `json:"page"`
+		Width  float64 `json:"width"`
+		Height float64 `json:"height"`
+		Blocks []struct {
+			Text  string     `json:"text"`
+			Label string     `json:"label"`
+			BBox  [4]float64 `json:"bbox"`
+		} `json:"blocks"`
+	} `json:"pages"`
+}
+
+func (r *ocrHTTPResponse) blocks() []types.LayoutBlock {
+	var blocks []types.LayoutBlock
+	for i, page := range r.Pages {
+		pageNo := page.Page
+		if pageNo <= 0 {
+			pageNo = i + 1
+		}
+		for _, b := range page.Blocks {
+			blocks = append(blocks, types.LayoutBlock{
+				LayoutRegion: types.LayoutRegion{
+					Page:       pageNo,
+					BBox:       b.BBox,
+					PageWidth:  page.Width,
+					PageHeight: page.Height,
+				},
+				Label: b.Label,
+				Text:  b.Text,
+			})
+		}
+	}
+	return blocks
+}
+
+// ApplyOCR writes the recognized blocks into result as markdown, one block
+// per paragraph, and records each block's offsets in result.LayoutBlocks.
+// The text of a PDF replaces the parser output (the page scans it holds are
+// dropped); the text of an image is put before its image reference, which
+// keeps the offsets valid when the reference is rewritten on storage.
+func ApplyOCR(result *types.ReadResult, blocks []types.LayoutBlock, fileType string) {
+	var b strings.Builder
+	var kept []types.LayoutBlock
+	offset := 0
+	for _, block := range blocks {
+		text := strings.TrimSpace(block.Text)
+		if text == "" {
+			continue
+		}
+		switch block.Label {
+		case "doc_title":
+			text = "# " + text
+		case "paragraph_title", "title":
+			text = "## " + text
+		}
+		if len(kept) > 0 {
+			b.WriteString("\n\n")
+			offset += 2
+		}
+		block.Text = text
+		block.Start = offset
+		block.End = offset + len([]rune(text))
+		b.WriteString(text)
+		offset = block.End
+		kept = append(kept, block)
+	}
+	if len(kept) == 0 {
+		return
+	}
+
+	if strings.ToLower(strings.TrimPrefix(fileType, ".")) == "pdf" {
+		result.MarkdownContent = b.String()
+		result.ImageRefs = nil
+	} else {
+		result.MarkdownContent = b.String() + "\n\n" + result.MarkdownContent
+	}
+	result.LayoutBlocks = kept
+}
+
+// LayoutRegions returns the regions of the blocks overlapping the rune range
+// [start, end) of the OCR text, in reading order.
+func LayoutRegions(blocks []types.LayoutBlock, start, end int) []types.LayoutRegion {
+	var regions []types.LayoutRegion
+	for _, block := range blocks {
+		if block.Start < end && start < block.End {
+			regions = append(regions, block.LayoutRegion)
+		}
+	}
+	return regions
+}
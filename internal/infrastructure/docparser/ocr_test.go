@@ -0,0 +1,101 @@
+package docparser
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestNeedsOCR(t *testing.T) {
+	scanned := &types.ReadResult{MarkdownContent: "![](page1.png)\n\n![](page2.png)\n\n1"}
+	if !NeedsOCR("pdf", scanned, false) {
+		t.Fatal("expected a PDF of page scans to need OCR")
+	}
+	text := &types.ReadResult{MarkdownContent: "# Annual report\n\nRevenue grew by ten percent this year."}
+	if NeedsOCR("pdf", text, false) {
+		t.Fatal("expected a PDF with a text layer not to need OCR")
+	}
+	image := &types.ReadResult{MarkdownContent: "![](scan.png)"}
+	if !NeedsOCR("png", image, false) || NeedsOCR("png", image, true) {
+		t.Fatal("expected images to need OCR only without multimodal parsing")
+	}
+	if NeedsOCR("docx", scanned, false) {
+		t.Fatal("expected only PDFs and images to be OCRed")
+	}
+}
+
+func TestOCRClientPaddleOCR(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/layout-parsing" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["fileType"] != float64(0) || body["file"] == "" {
+			t.Errorf("unexpected request %v", body)
+		}
+		_, _ = w.Write([]byte(`{"errorCode":0,"result":{"layoutParsingResults":[
+			{"prunedResult":{"width":1000,"height":1400,"parsing_res_list":[
+				{"block_label":"doc_title","block_content":"Invoice","block_bbox":[10,20,300,60]},
+				{"block_label":"text","block_content":"Total: 42 EUR","block_bbox":[10,100,400,130]}]}},
+			{"prunedResult":{"width":1000,"height":1400,"parsing_res_list":[
+				{"block_label":"text","block_content":"Thank you","block_bbox":[10,10,200,40]}]}}]}}`))
+	}))
+	defer server.Close()
+
+	client := NewOCRClient(map[string]string{"ocr_endpoint": server.URL + "/"})
+	blocks, err := client.Recognize(context.Background(), &types.ReadRequest{FileContent: []byte("%PDF"), FileType: "pdf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 3 || blocks[2].Page != 2 || blocks[1].BBox != [4]float64{10, 100, 400, 130} || blocks[0].PageHeight != 1400 {
+		t.Fatalf("unexpected blocks %+v", blocks)
+	}
+
+	result := &types.ReadResult{MarkdownContent: "![](page1.png)", ImageRefs: []types.ImageRef{{OriginalRef: "page1.png"}}}
+	ApplyOCR(result, blocks, "pdf")
+	if result.MarkdownContent != "# Invoice\n\nTotal: 42 EUR\n\nThank you" || result.ImageRefs != nil {
+		t.Fatalf("unexpected markdown %q", result.MarkdownContent)
+	}
+	runes := []rune(result.MarkdownContent)
+	for _, b := range result.LayoutBlocks {
+		if string(runes[b.Start:b.End]) != b.Text {
+			t.Errorf("offsets of %q point at %q", b.Text, string(runes[b.Start:b.End]))
+		}
+	}
+
+	regions := LayoutRegions(result.LayoutBlocks, 5, 15)
+	if len(regions) != 2 || regions[0].Page != 1 || regions[1].BBox[1] != 100 {
+		t.Fatalf("unexpected regions %+v", regions)
+	}
+}
+
+func TestOCRClientHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"pages":[{"width":800,"height":600,"blocks":[{"text":"扫描件正文","label":"text","bbox":[1,2,3,4]}]}]}`))
+	}))
+	defer server.Close()
+
+	client := NewOCRClient(map[string]string{"ocr_endpoint": server.URL, "ocr_provider": "http"})
+	blocks, err := client.Recognize(context.Background(), &types.ReadRequest{FileContent: []byte("png"), FileType: "png"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An image keeps its reference after the recognized text.
+	result := &types.ReadResult{MarkdownContent: "![](scan.png)"}
+	ApplyOCR(result, blocks, "png")
+	if result.MarkdownContent != "扫描件正文\n\n![](scan.png)" || result.LayoutBlocks[0].End != 5 || result.LayoutBlocks[0].Page != 1 {
+		t.Fatalf("unexpected result %q %+v", result.MarkdownContent, result.LayoutBlocks)
+	}
+}
+
+func TestNewOCRClientWithoutEndpoint(t *testing.T) {
+	if NewOCRClient(map[string]string{"ocr_provider": "http"}) != nil {
+		t.Fatal("expected no client without an endpoint")
+	}
+}
@@ -33,6 +33,10 @@ func (m *mockFileService) SaveBytes(ctx context.Context, data []byte, tenantID u
 func (m *mockFileService) GetFile(ctx context.Context, filePath string) (io.ReadCloser, error) {
 	return nil, nil
 }
+
+func (m *mockFileService) GetFileRange(ctx context.Context, filePath string, offset, length int64) (io.ReadCloser, int64, error) {
+	return nil, 0, nil
+}
 func (m *mockFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
 	return filePath, nil
 }
@@ -0,0 +1,106 @@
+package filescan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamAVChunkSize is the size of the INSTREAM chunks sent to clamd.
+const clamAVChunkSize = 64 << 10
+
+// ClamAVScanner scans files with a clamd daemon using the INSTREAM command,
+// so the daemon needs no access to the server's file system.
+type ClamAVScanner struct {
+	addr    string
+	timeout time.Duration
+}
+
+// NewClamAVScanner returns a scanner talking to clamd at addr (host:port).
+func NewClamAVScanner(addr string, timeout time.Duration) *ClamAVScanner {
+	return &ClamAVScanner{addr: addr, timeout: timeout}
+}
+
+// Name implements Scanner.
+func (s *ClamAVScanner) Name() string {
+	return "clamav"
+}
+
+// Scan implements Scanner.
+func (s *ClamAVScanner) Scan(ctx context.Context, r io.Reader, fileName string) (*Result, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd at %s: %w", s.addr, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	// The z prefix makes clamd expect a NUL-terminated command and answer
+	// with a NUL-terminated reply.
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("failed to send INSTREAM to clamd: %w", err)
+	}
+	buf := make([]byte, 4+clamAVChunkSize)
+	for {
+		n, readErr := r.Read(buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf[:4], uint32(n))
+			if _, err := conn.Write(buf[:4+n]); err != nil {
+				// clamd closes the connection once StreamMaxLength is
+				// exceeded; its reply explains why.
+				if reply, replyErr := readClamAVReply(conn); replyErr == nil {
+					return parseClamAVReply(reply)
+				}
+				return nil, fmt.Errorf("failed to stream file to clamd: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return nil, fmt.Errorf("failed to read file for scanning: %w", readErr)
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return nil, fmt.Errorf("failed to finish clamd stream: %w", err)
+	}
+
+	reply, err := readClamAVReply(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamAVReply(reply)
+}
+
+func readClamAVReply(conn net.Conn) (string, error) {
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(reply, "\x00\n"), nil
+}
+
+// parseClamAVReply interprets "stream: OK", "stream: <signature> FOUND" and
+// "<reason> ERROR" replies.
+func parseClamAVReply(reply string) (*Result, error) {
+	body := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case body == "OK":
+		return &Result{Verdict: VerdictClean}, nil
+	case strings.HasSuffix(body, " FOUND"):
+		return &Result{Verdict: VerdictInfected, Signature: strings.TrimSuffix(body, " FOUND")}, nil
+	default:
+		return nil, fmt.Errorf("clamd: %s", body)
+	}
+}
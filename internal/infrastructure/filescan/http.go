@@ -0,0 +1,73 @@
+package filescan
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// HTTPScanner delegates scanning to an external service. The file is POSTed
+// as the raw request body with its name in the X-File-Name header (URL
+// encoded) and, when a token is configured, an "Authorization: Bearer"
+// header. The service answers 200 with
+//
+//	{"infected": true, "signature": "Eicar-Test-Signature"}
+//
+// Any other status means the file could not be scanned.
+type HTTPScanner struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// httpScanResponse is the body an HTTP scanning service returns.
+type httpScanResponse struct {
+	Infected  bool   `json:"infected"`
+	Signature string `json:"signature"`
+}
+
+// NewHTTPScanner returns a scanner posting files to url.
+func NewHTTPScanner(url, token string, timeout time.Duration) *HTTPScanner {
+	return &HTTPScanner{url: url, token: token, client: &http.Client{Timeout: timeout}}
+}
+
+// Name implements Scanner.
+func (s *HTTPScanner) Name() string {
+	return "http"
+}
+
+// Scan implements Scanner.
+func (s *HTTPScanner) Scan(ctx context.Context, r io.Reader, fileName string) (*Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build scan request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-File-Name", url.PathEscape(fileName))
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("scan request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("scanner returned %d: %s", resp.StatusCode, body)
+	}
+
+	var out httpScanResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode scan response: %w", err)
+	}
+	if out.Infected {
+		return &Result{Verdict: VerdictInfected, Signature: out.Signature}, nil
+	}
+	return &Result{Verdict: VerdictClean}, nil
+}
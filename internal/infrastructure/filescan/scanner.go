@@ -0,0 +1,107 @@
+// Package filescan checks uploaded files for malware before they are stored
+// and handed to ingestion. The scanner is chosen with FILE_SCAN_PROVIDER:
+// "clamav" streams files to a clamd daemon over TCP, "http" posts them to a
+// pluggable scanning service. Scanning is off when the variable is unset.
+package filescan
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	envProvider    = "FILE_SCAN_PROVIDER"
+	envClamAVAddr  = "FILE_SCAN_CLAMAV_ADDR"
+	envHTTPURL     = "FILE_SCAN_HTTP_URL"
+	envHTTPToken   = "FILE_SCAN_HTTP_TOKEN"
+	envTimeout     = "FILE_SCAN_TIMEOUT_SECONDS"
+	envAction      = "FILE_SCAN_ACTION"
+	envFailOpen    = "FILE_SCAN_FAIL_OPEN"
+	defaultTimeout = 60 * time.Second
+	defaultClamAV  = "localhost:3310"
+)
+
+// Actions taken on an infected upload.
+const (
+	// ActionReject refuses the upload; nothing is stored.
+	ActionReject = "reject"
+	// ActionQuarantine stores the upload but keeps it out of ingestion.
+	ActionQuarantine = "quarantine"
+)
+
+// Verdict is the outcome of scanning one file.
+type Verdict string
+
+const (
+	VerdictClean    Verdict = "clean"
+	VerdictInfected Verdict = "infected"
+)
+
+// Result is a scanner's verdict on one file.
+type Result struct {
+	Verdict Verdict
+	// Signature names the detected threat; empty for clean files.
+	Signature string
+}
+
+// Infected reports whether the scanner found a threat.
+func (r *Result) Infected() bool {
+	return r != nil && r.Verdict == VerdictInfected
+}
+
+// Scanner scans file contents for malware. A returned error means the file
+// could not be scanned, not that it is infected.
+type Scanner interface {
+	// Name identifies the scanner in logs and audit records.
+	Name() string
+	Scan(ctx context.Context, r io.Reader, fileName string) (*Result, error)
+}
+
+// NewFromEnv returns the scanner configured by the FILE_SCAN_* variables,
+// or nil when scanning is disabled.
+func NewFromEnv() (Scanner, error) {
+	timeout := defaultTimeout
+	if s, err := strconv.Atoi(strings.TrimSpace(os.Getenv(envTimeout))); err == nil && s > 0 {
+		timeout = time.Duration(s) * time.Second
+	}
+
+	switch provider := strings.ToLower(strings.TrimSpace(os.Getenv(envProvider))); provider {
+	case "":
+		return nil, nil
+	case "clamav":
+		addr := strings.TrimSpace(os.Getenv(envClamAVAddr))
+		if addr == "" {
+			addr = defaultClamAV
+		}
+		return NewClamAVScanner(addr, timeout), nil
+	case "http":
+		url := strings.TrimSpace(os.Getenv(envHTTPURL))
+		if url == "" {
+			return nil, fmt.Errorf("%s is required when %s=http", envHTTPURL, envProvider)
+		}
+		return NewHTTPScanner(url, os.Getenv(envHTTPToken), timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown %s %q, expected clamav or http", envProvider, provider)
+	}
+}
+
+// DefaultAction is the action for infected uploads of tenants that do not
+// choose one: FILE_SCAN_ACTION, or reject.
+func DefaultAction() string {
+	if strings.EqualFold(strings.TrimSpace(os.Getenv(envAction)), ActionQuarantine) {
+		return ActionQuarantine
+	}
+	return ActionReject
+}
+
+// FailOpen reports whether uploads are accepted when the scanner cannot be
+// reached (FILE_SCAN_FAIL_OPEN=true). By default they are refused.
+func FailOpen() bool {
+	v, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv(envFailOpen)))
+	return v
+}
@@ -0,0 +1,121 @@
+package filescan
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd accepts one INSTREAM session and flags streams containing the
+// EICAR test string.
+func fakeClamd(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				if cmd, err := r.ReadString(0); err != nil || cmd != "zINSTREAM\x00" {
+					return
+				}
+				var data bytes.Buffer
+				for {
+					var size uint32
+					if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if _, err := io.CopyN(&data, r, int64(size)); err != nil {
+						return
+					}
+				}
+				if strings.Contains(data.String(), "EICAR-STANDARD-ANTIVIRUS-TEST-FILE") {
+					conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+				} else {
+					conn.Write([]byte("stream: OK\x00"))
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+func TestClamAVScanner(t *testing.T) {
+	scanner := NewClamAVScanner(fakeClamd(t), 5*time.Second)
+
+	res, err := scanner.Scan(context.Background(), strings.NewReader(strings.Repeat("clean ", 50000)), "a.txt")
+	if err != nil || res.Infected() {
+		t.Fatalf("clean file: result=%+v err=%v", res, err)
+	}
+	res, err = scanner.Scan(context.Background(), strings.NewReader(eicar), "eicar.com")
+	if err != nil || !res.Infected() || res.Signature != "Eicar-Signature" {
+		t.Fatalf("EICAR file: result=%+v err=%v", res, err)
+	}
+}
+
+func TestParseClamAVReplyError(t *testing.T) {
+	if _, err := parseClamAVReply("INSTREAM size limit exceeded. ERROR"); err == nil {
+		t.Fatal("expected an error reply to fail the scan")
+	}
+}
+
+func TestHTTPScanner(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		infected := strings.Contains(string(body), "EICAR")
+		json.NewEncoder(w).Encode(httpScanResponse{Infected: infected, Signature: map[bool]string{true: "EICAR"}[infected]})
+	}))
+	defer srv.Close()
+
+	scanner := NewHTTPScanner(srv.URL, "secret", 5*time.Second)
+	res, err := scanner.Scan(context.Background(), strings.NewReader(eicar), "a b.com")
+	if err != nil || !res.Infected() || res.Signature != "EICAR" {
+		t.Fatalf("result=%+v err=%v", res, err)
+	}
+
+	if _, err := NewHTTPScanner(srv.URL, "", 5*time.Second).Scan(context.Background(), strings.NewReader("x"), "a"); err == nil {
+		t.Fatal("expected a non-200 reply to fail the scan")
+	}
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv(envProvider, "")
+	if s, err := NewFromEnv(); s != nil || err != nil {
+		t.Fatalf("unset provider: scanner=%v err=%v", s, err)
+	}
+	t.Setenv(envProvider, "http")
+	t.Setenv(envHTTPURL, "")
+	if _, err := NewFromEnv(); err == nil {
+		t.Fatal("expected http provider without URL to fail")
+	}
+	t.Setenv(envProvider, "ClamAV")
+	if s, err := NewFromEnv(); err != nil || s.Name() != "clamav" {
+		t.Fatalf("clamav provider: scanner=%v err=%v", s, err)
+	}
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/natefinch/lumberjack.v2"
 )
 
@@ -521,5 +522,11 @@ func CloneContext(ctx context.Context) context.Context {
 		}
 	}
 
+	// Carry the OpenTelemetry span context over too, so spans opened by
+	// background work stay in the trace of the request that started it.
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		newCtx = trace.ContextWithSpanContext(newCtx, sc)
+	}
+
 	return newCtx
 }
@@ -0,0 +1,196 @@
+// Package metrics exposes Prometheus metrics for retrieval, ingestion, model
+// calls, the chat pipeline and caches, all labeled by tenant.
+//
+// Collectors live on the default registry, so Handler also serves the Go
+// runtime and process metrics. Every Observe/Record helper is cheap and safe
+// to call from hot paths; callers never check whether the endpoint is on.
+//
+// The endpoint is tuned with:
+//
+//	METRICS_ENABLED  set to "false" to stop serving /metrics (default on)
+//	METRICS_TOKEN    when set, scrapes must send "Authorization: Bearer <token>"
+package metrics
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "weknora"
+
+// Status label values.
+const (
+	StatusSuccess = "success"
+	StatusError   = "error"
+)
+
+// Cache names for RecordCache.
+const (
+	CacheRetrieve    = "retrieve"
+	CacheLLMResponse = "llm_response"
+)
+
+// unknownTenant labels work done outside any tenant, e.g. startup tasks.
+const unknownTenant = "unknown"
+
+// latencyBuckets spans fast cache-backed lookups to slow LLM generations.
+var latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// stageBuckets spans ingestion stages, which take seconds to many minutes.
+var stageBuckets = []float64{.1, .5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800}
+
+var (
+	retrievalDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "retrieval_duration_seconds",
+		Help:      "Latency of retrieval engine queries, cache hits excluded.",
+		Buckets:   latencyBuckets,
+	}, []string{"tenant", "engine", "retriever", "status"})
+
+	vectorStoreDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "vector_store_operation_duration_seconds",
+		Help:      "Latency of vector store writes and deletes.",
+		Buckets:   latencyBuckets,
+	}, []string{"tenant", "engine", "operation", "status"})
+
+	ingestionStageDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ingestion_stage_duration_seconds",
+		Help:      "Duration of document ingestion stages.",
+		Buckets:   stageBuckets,
+	}, []string{"tenant", "stage", "status"})
+
+	modelDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "model_request_duration_seconds",
+		Help:      "Latency of chat and embedding model calls; streams are timed until the last chunk.",
+		Buckets:   latencyBuckets,
+	}, []string{"tenant", "kind", "model", "status"})
+
+	modelTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "model_tokens_total",
+		Help:      "Tokens spent on model calls.",
+	}, []string{"tenant", "kind", "model", "type"})
+
+	pipelineErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "pipeline_stage_errors_total",
+		Help:      "Chat pipeline stages that ended with an error.",
+	}, []string{"tenant", "stage", "error_type"})
+
+	cacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Cache lookups by result; the hit rate is hit / (hit + miss).",
+	}, []string{"tenant", "cache", "result"})
+)
+
+// ObserveRetrieval records one retrieval engine query.
+func ObserveRetrieval(ctx context.Context, engine types.RetrieverEngineType, retriever types.RetrieverType,
+	start time.Time, err error,
+) {
+	retrievalDuration.WithLabelValues(tenant(ctx), string(engine), string(retriever), status(err)).
+		Observe(time.Since(start).Seconds())
+}
+
+// ObserveVectorStore records one vector store write or delete.
+func ObserveVectorStore(ctx context.Context, engine types.RetrieverEngineType, operation string,
+	start time.Time, err error,
+) {
+	vectorStoreDuration.WithLabelValues(tenant(ctx), string(engine), operation, status(err)).
+		Observe(time.Since(start).Seconds())
+}
+
+// ObserveIngestionStage records a finished ingestion stage; status is
+// StatusSuccess or StatusError.
+func ObserveIngestionStage(ctx context.Context, stage, status string, d time.Duration) {
+	ingestionStageDuration.WithLabelValues(tenant(ctx), stage, status).Observe(d.Seconds())
+}
+
+// ObserveModelCall records one model call.
+func ObserveModelCall(ctx context.Context, kind types.TokenUsageKind, model string, start time.Time, err error) {
+	modelDuration.WithLabelValues(tenant(ctx), string(kind), model, status(err)).Observe(time.Since(start).Seconds())
+}
+
+// RecordTokens counts the tokens of a usage event.
+func RecordTokens(ctx context.Context, event *types.TokenUsageEvent) {
+	if event == nil {
+		return
+	}
+	t, kind := tenant(ctx), string(event.Kind)
+	for tokenType, n := range map[string]int64{
+		"prompt":     event.PromptTokens,
+		"completion": event.CompletionTokens,
+		"embedding":  event.EmbeddingTokens,
+	} {
+		if n > 0 {
+			modelTokens.WithLabelValues(t, kind, event.ModelName, tokenType).Add(float64(n))
+		}
+	}
+}
+
+// RecordPipelineError counts a chat pipeline stage that failed.
+func RecordPipelineError(ctx context.Context, stage, errorType string) {
+	pipelineErrors.WithLabelValues(tenant(ctx), stage, errorType).Inc()
+}
+
+// RecordCache counts a cache lookup.
+func RecordCache(ctx context.Context, cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheRequests.WithLabelValues(tenant(ctx), cache, result).Inc()
+}
+
+// Enabled reports whether /metrics is served.
+func Enabled() bool {
+	return !strings.EqualFold(strings.TrimSpace(os.Getenv("METRICS_ENABLED")), "false")
+}
+
+// Handler serves the metrics, requiring METRICS_TOKEN as a bearer token
+// when it is set.
+func Handler() http.Handler {
+	h := promhttp.Handler()
+	token := strings.TrimSpace(os.Getenv("METRICS_TOKEN"))
+	if token == "" {
+		return h
+	}
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func tenant(ctx context.Context) string {
+	if ctx == nil {
+		return unknownTenant
+	}
+	if id, ok := types.TenantIDFromContext(ctx); ok && id != 0 {
+		return strconv.FormatUint(id, 10)
+	}
+	return unknownTenant
+}
+
+func status(err error) string {
+	if err != nil {
+		return StatusError
+	}
+	return StatusSuccess
+}
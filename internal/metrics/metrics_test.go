@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTenantLabel(t *testing.T) {
+	assert.Equal(t, unknownTenant, tenant(context.Background()))
+	ctx := context.WithValue(context.Background(), types.TenantIDContextKey, uint64(10000))
+	assert.Equal(t, "10000", tenant(ctx))
+}
+
+func TestHandlerToken(t *testing.T) {
+	t.Setenv("METRICS_TOKEN", "secret")
+	h := Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.NotEqual(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestEnabled(t *testing.T) {
+	t.Setenv("METRICS_ENABLED", "")
+	assert.True(t, Enabled())
+	t.Setenv("METRICS_ENABLED", "false")
+	assert.False(t, Enabled())
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestAPIKeyScopeAllows(t *testing.T) {
+	key := func(scopes ...string) *types.APIKey { return &types.APIKey{Scopes: scopes} }
+
+	cases := []struct {
+		name     string
+		key      *types.APIKey
+		method   string
+		fullPath string
+		want     bool
+	}{
+		{"all covers admin routes", key(types.APIKeyScopeAll), http.MethodDelete, "/api/v1/knowledge-bases/:id", true},
+		{"read covers GET", key(types.APIKeyScopeRead), http.MethodGet, "/api/v1/knowledge-bases/:id", true},
+		{"read does not cover writes", key(types.APIKeyScopeRead), http.MethodPost, "/api/v1/knowledge-bases", false},
+		{"chat covers chat", key(types.APIKeyScopeChat), http.MethodPost, "/api/v1/knowledge-chat/:session_id", true},
+		{"chat covers sessions", key(types.APIKeyScopeChat), http.MethodPost, "/api/v1/sessions", true},
+		{"chat covers kb search", key(types.APIKeyScopeChat), http.MethodPost, "/api/v1/knowledge-bases/:id/hybrid-search", true},
+		{"chat does not cover upload", key(types.APIKeyScopeChat), http.MethodPost, "/api/v1/knowledge-bases/:id/knowledge/file", false},
+		{"ingest covers upload", key(types.APIKeyScopeIngest), http.MethodPost, "/api/v1/knowledge-bases/:id/knowledge/file", true},
+		{"ingest covers faq", key(types.APIKeyScopeIngest), http.MethodPost, "/api/v1/knowledge-bases/:id/faq/entries", true},
+		{"ingest does not cover kb settings", key(types.APIKeyScopeIngest), http.MethodPut, "/api/v1/knowledge-bases/:id", false},
+		{"prefix match stops at segment", key(types.APIKeyScopeIngest), http.MethodGet, "/api/v1/knowledge-bases", false},
+		{"read does not cover tenant settings", key(types.APIKeyScopeRead), http.MethodGet, "/api/v1/tenants/:id", false},
+		{"read does not cover tenant kv", key(types.APIKeyScopeRead), http.MethodGet, "/api/v1/tenants/kv/:key", false},
+		{"read does not cover key management", key(types.APIKeyScopeRead), http.MethodGet, "/api/v1/api-keys", false},
+		{"read exclusion stops at segment", key(types.APIKeyScopeRead), http.MethodGet, "/api/v1/tenants-usage", true},
+		{"all covers tenant settings", key(types.APIKeyScopeAll), http.MethodGet, "/api/v1/tenants/:id", true},
+		{"unmatched route passes through", key(types.APIKeyScopeChat), http.MethodPost, "", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := apiKeyScopeAllows(tc.key, tc.method, tc.fullPath); got != tc.want {
+				t.Fatalf("apiKeyScopeAllows(%v, %s, %s) = %v, want %v",
+					tc.key.Scopes, tc.method, tc.fullPath, got, tc.want)
+			}
+		})
+	}
+}
@@ -67,6 +67,7 @@ func Auth(
 	tenantService interfaces.TenantService,
 	userService interfaces.UserService,
 	memberService interfaces.TenantMemberService,
+	apiKeyService interfaces.APIKeyService,
 	cfg *config.Config,
 ) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -189,8 +190,17 @@ func Auth(
 			}
 		}
 
-		// 尝试X-API-Key认证（兼容模式）
+		// 尝试X-API-Key认证：wk- 开头的是带 scope 的托管 API Key，其余为租户级兼容 Key
 		apiKey := c.GetHeader("X-API-Key")
+		if apiKey != "" && strings.HasPrefix(apiKey, types.APIKeySecretPrefix) {
+			managedKey, t, ok := authenticateManagedAPIKey(c, apiKey, apiKeyService, tenantService)
+			if !ok {
+				return
+			}
+			setAPIKeyContext(c, userService, t, managedKey.Role, managedKey)
+			c.Next()
+			return
+		}
 		if apiKey != "" {
 			// Get tenant information
 			tenantID, err := tenantService.ExtractTenantIDFromAPIKey(apiKey)
@@ -221,52 +231,7 @@ func Auth(
 				return
 			}
 
-			// 存储租户和用户信息到上下文
-			c.Set(types.TenantIDContextKey.String(), tenantID)
-			c.Set(types.TenantInfoContextKey.String(), t)
-
-			ctx := context.WithValue(
-				context.WithValue(c.Request.Context(), types.TenantIDContextKey, tenantID),
-				types.TenantInfoContextKey, t,
-			)
-
-			// 通过 TenantID 关联查询用户；找不到时构造系统虚拟用户，
-			// 确保所有依赖 UserContextKey 的下游 handler 正常工作。
-			user, err := userService.GetUserByTenantID(c.Request.Context(), tenantID)
-			if err != nil || user == nil {
-				// Synthetic user. The "system-<tenantID>" shape is recognised
-				// by types.IsSyntheticUserID, which RBAC service-layer code
-				// uses to skip recording these IDs as a resource creator.
-				// Do NOT change the prefix or numeric suffix without
-				// updating that helper, otherwise KB/Agent CreatorID will
-				// silently start pointing at the synthetic user again.
-				user = &types.User{
-					ID:       fmt.Sprintf("system-%d", tenantID),
-					Username: fmt.Sprintf("system-%d", tenantID),
-					Email:    fmt.Sprintf("system-%d@api-key.local", tenantID),
-					TenantID: tenantID,
-					IsActive: true,
-				}
-				log.Printf("No user found for tenant %d via API key, using synthetic system user %s", tenantID, user.ID)
-			}
-			// API-Key 走的是程序化全租户访问，固定授予 Admin 角色：可以做几乎所有事情，
-			// 但保留 Owner-only 操作（删除租户、修改租户级配置）的边界。
-			//
-			// 显式拒绝 SystemAdmin：API key 通常被存放在 CI / IaC / sidecar 里，
-			// 泄露面比 JWT 大得多。即便 key 关联的 user 在 DB 里恰好是 SystemAdmin
-			// （例如部署里只有一个用户、自己创建了 tenant 又生成了 API key），
-			// 也绝不允许通过这条通道走平台级管理操作（promote/revoke、全局设置）。
-			// 平台管理必须走交互式 JWT 登录，留下可追责的人类身份。
-			c.Set(types.UserContextKey.String(), user)
-			c.Set(types.UserIDContextKey.String(), user.ID)
-			c.Set(types.TenantRoleContextKey.String(), types.TenantRoleAdmin)
-			c.Set(types.SystemAdminContextKey.String(), false)
-			ctx = context.WithValue(ctx, types.UserContextKey, user)
-			ctx = context.WithValue(ctx, types.UserIDContextKey, user.ID)
-			ctx = context.WithValue(ctx, types.TenantRoleContextKey, types.TenantRoleAdmin)
-			ctx = context.WithValue(ctx, types.SystemAdminContextKey, false)
-
-			c.Request = c.Request.WithContext(ctx)
+			setAPIKeyContext(c, userService, t, t.EffectiveAPIKeyRole(), nil)
 			c.Next()
 			return
 		}
@@ -277,6 +242,72 @@ func Auth(
 	}
 }
 
+// setAPIKeyContext 把 API Key 请求的租户、用户与角色写入 gin 与 request 上下文。
+// managedKey 为 nil 表示租户级兼容 Key。
+func setAPIKeyContext(
+	c *gin.Context,
+	userService interfaces.UserService,
+	t *types.Tenant,
+	apiKeyRole types.TenantRole,
+	managedKey *types.APIKey,
+) {
+	tenantID := t.ID
+
+	// 存储租户和用户信息到上下文
+	c.Set(types.TenantIDContextKey.String(), tenantID)
+	c.Set(types.TenantInfoContextKey.String(), t)
+
+	ctx := context.WithValue(
+		context.WithValue(c.Request.Context(), types.TenantIDContextKey, tenantID),
+		types.TenantInfoContextKey, t,
+	)
+
+	// 通过 TenantID 关联查询用户；找不到时构造系统虚拟用户，
+	// 确保所有依赖 UserContextKey 的下游 handler 正常工作。
+	user, err := userService.GetUserByTenantID(c.Request.Context(), tenantID)
+	if err != nil || user == nil {
+		// Synthetic user. The "system-<tenantID>" shape is recognised
+		// by types.IsSyntheticUserID, which RBAC service-layer code
+		// uses to skip recording these IDs as a resource creator.
+		// Do NOT change the prefix or numeric suffix without
+		// updating that helper, otherwise KB/Agent CreatorID will
+		// silently start pointing at the synthetic user again.
+		user = &types.User{
+			ID:       fmt.Sprintf("system-%d", tenantID),
+			Username: fmt.Sprintf("system-%d", tenantID),
+			Email:    fmt.Sprintf("system-%d@api-key.local", tenantID),
+			TenantID: tenantID,
+			IsActive: true,
+		}
+		log.Printf("No user found for tenant %d via API key, using synthetic system user %s", tenantID, user.ID)
+	}
+	// API-Key 走的是程序化全租户访问，角色由租户的 api_key_role 决定（默认 Admin），
+	// Owner 可以把它降为 Contributor / Viewer 以收窄 key 泄露后的影响面。
+	// 无论如何都不会授予 Owner：保留 Owner-only 操作（删除租户、修改租户级配置）的边界。
+	//
+	// 显式拒绝 SystemAdmin：API key 通常被存放在 CI / IaC / sidecar 里，
+	// 泄露面比 JWT 大得多。即便 key 关联的 user 在 DB 里恰好是 SystemAdmin
+	// （例如部署里只有一个用户、自己创建了 tenant 又生成了 API key），
+	// 也绝不允许通过这条通道走平台级管理操作（promote/revoke、全局设置）。
+	// 平台管理必须走交互式 JWT 登录，留下可追责的人类身份。
+	c.Set(types.UserContextKey.String(), user)
+	c.Set(types.UserIDContextKey.String(), user.ID)
+	c.Set(types.TenantRoleContextKey.String(), apiKeyRole)
+	c.Set(types.SystemAdminContextKey.String(), false)
+	c.Set(types.APIKeyAuthContextKey.String(), true)
+	ctx = context.WithValue(ctx, types.UserContextKey, user)
+	ctx = context.WithValue(ctx, types.UserIDContextKey, user.ID)
+	ctx = context.WithValue(ctx, types.TenantRoleContextKey, apiKeyRole)
+	ctx = context.WithValue(ctx, types.SystemAdminContextKey, false)
+	ctx = context.WithValue(ctx, types.APIKeyAuthContextKey, true)
+	if managedKey != nil {
+		c.Set(types.APIKeyContextKey.String(), managedKey)
+		ctx = context.WithValue(ctx, types.APIKeyContextKey, managedKey)
+	}
+
+	c.Request = c.Request.WithContext(ctx)
+}
+
 // resolveTenantRole determines the caller's TenantRole inside targetTenantID.
 //
 // Order of resolution:
@@ -164,6 +164,9 @@ func (f *fakeTenantService) ListTenants(ctx context.Context) ([]*types.Tenant, e
 func (f *fakeTenantService) UpdateAPIKey(ctx context.Context, id uint64) (string, error) {
 	return "", nil
 }
+func (f *fakeTenantService) UpdateAPIKeyRole(ctx context.Context, id uint64, role types.TenantRole) (*types.Tenant, error) {
+	return nil, nil
+}
 
 func (f *fakeTenantService) ExtractTenantIDFromAPIKey(apiKey string) (uint64, error) {
 	return 0, nil
@@ -239,6 +239,14 @@ func RequireKBAccess(
 
 		ctx := c.Request.Context()
 
+		// A managed API key restricted to some knowledge bases never
+		// reaches the others, whatever its role and the rollout flag say.
+		if key, ok := types.APIKeyFromContext(ctx); ok && !key.AllowsKnowledgeBase(kbID) {
+			_ = c.Error(apperrors.NewForbiddenError("API key is not allowed to access this knowledge base"))
+			c.Abort()
+			return
+		}
+
 		// Rollout window: enforcement off -> log the would-be check and
 		// pass through. We still resolve the KB (best-effort) so the
 		// effective-tenant context rewrite still happens for shared
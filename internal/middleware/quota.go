@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/ratelimit"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// quota.go — per-tenant API limits configured by the `rate_limit:` config
+// section (requests per minute, requests per API key, concurrent chats and
+// daily uploads) plus the tenant storage quota check for uploads. Every
+// rejection is a 429 AppError whose details name the limit that was hit,
+// with a Retry-After header when waiting helps.
+
+const (
+	quotaRequestKeyPrefix = "quota:requests:"
+	quotaUploadKeyPrefix  = "quota:uploads:"
+	quotaChatKeyPrefix    = "quota:chats:"
+
+	// quotaChatLease frees the chat slot of a crashed instance. It exceeds
+	// the longest agent run so a live chat never loses its slot.
+	quotaChatLease = time.Hour
+	// quotaChatRetryAfter is the wait suggested when all chat slots are
+	// busy; there is no way to know when one frees up.
+	quotaChatRetryAfter = 5 * time.Second
+)
+
+// TenantQuotas enforces the per-tenant limits of config.RateLimitConfig.
+// Each middleware passes requests through when its limit is zero and when
+// the request carries no tenant (the public auth endpoints).
+type TenantQuotas struct {
+	cfg      config.RateLimitConfig
+	requests *ratelimit.Limiter
+	uploads  *ratelimit.Limiter
+	chats    *ratelimit.Semaphore
+}
+
+// NewTenantQuotas builds the limiters. redisClient may be nil, in which case
+// every instance counts on its own.
+func NewTenantQuotas(cfg *config.Config, redisClient *redis.Client) *TenantQuotas {
+	q := &TenantQuotas{
+		requests: ratelimit.New(redisClient, quotaRequestKeyPrefix, time.Minute, ""),
+		uploads:  ratelimit.New(redisClient, quotaUploadKeyPrefix, 24*time.Hour, ""),
+		chats:    ratelimit.NewSemaphore(redisClient, quotaChatKeyPrefix, quotaChatLease, ""),
+	}
+	if cfg != nil && cfg.RateLimit != nil {
+		q.cfg = *cfg.RateLimit
+	}
+	// Local-fallback eviction; Redis keys expire via PEXPIRE in the Lua script.
+	stopCh := make(chan struct{})
+	go q.requests.StartCleanup(stopCh)
+	go q.uploads.StartCleanup(stopCh)
+	return q
+}
+
+// RequestRate limits the authenticated requests per tenant and, for calls
+// made with an X-API-Key, per API key. It must run after Auth.
+func (q *TenantQuotas) RequestRate() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := c.GetUint64(types.TenantIDContextKey.String())
+		if tenantID == 0 {
+			c.Next()
+			return
+		}
+		ctx := c.Request.Context()
+
+		key := strconv.FormatUint(tenantID, 10)
+		if ok, retryAfter := q.requests.Check(ctx, key, q.cfg.RequestsPerMinute); !ok {
+			q.reject(c, "requests_per_minute", q.cfg.RequestsPerMinute, retryAfter)
+			return
+		}
+		if c.GetBool(types.APIKeyAuthContextKey.String()) {
+			key := apiKeyQuotaKey(c)
+			if ok, retryAfter := q.requests.Check(ctx, key, q.cfg.APIKeyRequestsPerMinute); !ok {
+				q.reject(c, "api_key_requests_per_minute", q.cfg.APIKeyRequestsPerMinute, retryAfter)
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// apiKeyQuotaKey identifies the API key a request authenticated with by a
+// hash of the header, so every key, managed or not, has its own budget.
+func apiKeyQuotaKey(c *gin.Context) string {
+	sum := sha256.Sum256([]byte(c.GetHeader("X-API-Key")))
+	return "apikey:" + hex.EncodeToString(sum[:8])
+}
+
+// ConcurrentChats holds one of the tenant's chat slots for the whole
+// request, i.e. until the answer stream ends.
+func (q *TenantQuotas) ConcurrentChats() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := c.GetUint64(types.TenantIDContextKey.String())
+		if tenantID == 0 || q.cfg.ConcurrentChats <= 0 {
+			c.Next()
+			return
+		}
+		release, ok := q.chats.Acquire(c.Request.Context(), strconv.FormatUint(tenantID, 10), q.cfg.ConcurrentChats)
+		if !ok {
+			q.reject(c, "concurrent_chats", q.cfg.ConcurrentChats, quotaChatRetryAfter)
+			return
+		}
+		defer release()
+		c.Next()
+	}
+}
+
+// Uploads rejects a document upload when the tenant has used up its daily
+// uploads, or when the upload cannot fit in the tenant storage quota. The
+// upload size is taken from Content-Length, so it slightly overestimates
+// multipart bodies.
+func (q *TenantQuotas) Uploads() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenant, ok := types.TenantInfoFromContext(c.Request.Context())
+		if !ok {
+			c.Next()
+			return
+		}
+		if tenant.StorageQuota > 0 {
+			size := c.Request.ContentLength
+			if size < 0 {
+				size = 0
+			}
+			if tenant.StorageUsed >= tenant.StorageQuota || tenant.StorageUsed+size > tenant.StorageQuota {
+				logger.Warnf(c.Request.Context(), "Tenant %d storage quota exceeded: used=%d, upload=%d, quota=%d",
+					tenant.ID, tenant.StorageUsed, size, tenant.StorageQuota)
+				c.Error(apperrors.NewTenantStorageQuotaExceededError(tenant.StorageUsed, tenant.StorageQuota))
+				c.Abort()
+				return
+			}
+		}
+		key := strconv.FormatUint(tenant.ID, 10)
+		if ok, retryAfter := q.uploads.Check(c.Request.Context(), key, q.cfg.DailyUploads); !ok {
+			q.reject(c, "daily_uploads", q.cfg.DailyUploads, retryAfter)
+			return
+		}
+		c.Next()
+	}
+}
+
+// reject aborts the request with a structured 429 and a Retry-After header.
+func (q *TenantQuotas) reject(c *gin.Context, limit string, max int, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	logger.Warnf(c.Request.Context(), "Tenant %d hit rate limit %s (max %d), retry after %ds",
+		c.GetUint64(types.TenantIDContextKey.String()), limit, max, seconds)
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.Error(apperrors.NewTenantRateLimitedError(limit, max, seconds))
+	c.Abort()
+}
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/config"
+	apperrors "github.com/Tencent/WeKnora/internal/errors"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/gin-gonic/gin"
+)
+
+// newQuotaRouter serves POST /x behind the given quota middleware, with the
+// tenant the Auth middleware would have attached.
+func newQuotaRouter(tenant *types.Tenant, quota gin.HandlerFunc, handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.Use(func(c *gin.Context) {
+		c.Set(types.TenantIDContextKey.String(), tenant.ID)
+		c.Set(types.TenantInfoContextKey.String(), tenant)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), types.TenantInfoContextKey, tenant))
+		c.Next()
+	})
+	r.POST("/x", quota, handler)
+	return r
+}
+
+func okHandler(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"success": true}) }
+
+type quotaErrorBody struct {
+	Error struct {
+		Code    apperrors.ErrorCode `json:"code"`
+		Details map[string]any      `json:"details"`
+	} `json:"error"`
+}
+
+func decodeQuotaError(t *testing.T, w *httptest.ResponseRecorder) quotaErrorBody {
+	t.Helper()
+	var body quotaErrorBody
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body %q: %v", w.Body.String(), err)
+	}
+	return body
+}
+
+func TestTenantQuotasRequestRate(t *testing.T) {
+	q := NewTenantQuotas(&config.Config{RateLimit: &config.RateLimitConfig{RequestsPerMinute: 2}}, nil)
+	r := newQuotaRouter(&types.Tenant{ID: 7}, q.RequestRate(), okHandler)
+	other := newQuotaRouter(&types.Tenant{ID: 8}, q.RequestRate(), okHandler)
+
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/x", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: got %d, want 200", i+1, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/x", nil))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("got %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Fatal("429 should carry a Retry-After header")
+	}
+	body := decodeQuotaError(t, w)
+	if body.Error.Code != apperrors.ErrTenantRateLimited || body.Error.Details["limit"] != "requests_per_minute" {
+		t.Fatalf("unexpected error body: %s", w.Body.String())
+	}
+
+	// Tenants are limited separately.
+	w = httptest.NewRecorder()
+	other.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/x", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("other tenant: got %d, want 200", w.Code)
+	}
+}
+
+func TestTenantQuotasAPIKeyRequestRate(t *testing.T) {
+	q := NewTenantQuotas(&config.Config{RateLimit: &config.RateLimitConfig{APIKeyRequestsPerMinute: 1}}, nil)
+	tenant := &types.Tenant{ID: 9}
+	// serve mimics Auth for a tenant with a registered user: API key calls
+	// run as that real user, not as the synthetic system user.
+	serve := func(apiKey string) int {
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.Use(ErrorHandler())
+		r.Use(func(c *gin.Context) {
+			ctx := c.Request.Context()
+			c.Set(types.TenantIDContextKey.String(), tenant.ID)
+			c.Set(types.UserIDContextKey.String(), "user-1")
+			if apiKey != "" {
+				c.Set(types.APIKeyAuthContextKey.String(), true)
+				ctx = context.WithValue(ctx, types.APIKeyAuthContextKey, true)
+			}
+			c.Request = c.Request.WithContext(ctx)
+			c.Next()
+		})
+		r.POST("/x", q.RequestRate(), okHandler)
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/x", nil)
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := serve("sk-tenant"); code != http.StatusOK {
+		t.Fatalf("first tenant key request: got %d, want 200", code)
+	}
+	if code := serve("sk-tenant"); code != http.StatusTooManyRequests {
+		t.Fatalf("second tenant key request: got %d, want 429", code)
+	}
+
+	// Every key has its own budget.
+	if code := serve("wk-one"); code != http.StatusOK {
+		t.Fatalf("first managed key request: got %d, want 200", code)
+	}
+	if code := serve("wk-one"); code != http.StatusTooManyRequests {
+		t.Fatalf("second managed key request: got %d, want 429", code)
+	}
+	if code := serve("wk-two"); code != http.StatusOK {
+		t.Fatalf("other managed key: got %d, want 200", code)
+	}
+
+	// Logged-in users are not subject to the API key limit.
+	for i := 0; i < 2; i++ {
+		if code := serve(""); code != http.StatusOK {
+			t.Fatalf("JWT request %d: got %d, want 200", i+1, code)
+		}
+	}
+}
+
+func TestTenantQuotasConcurrentChats(t *testing.T) {
+	q := NewTenantQuotas(&config.Config{RateLimit: &config.RateLimitConfig{ConcurrentChats: 1}}, nil)
+	tenant := &types.Tenant{ID: 7}
+
+	var inner *httptest.ResponseRecorder
+	var r *gin.Engine
+	r = newQuotaRouter(tenant, q.ConcurrentChats(), func(c *gin.Context) {
+		// A second chat while this one is still streaming is rejected.
+		inner = httptest.NewRecorder()
+		r.ServeHTTP(inner, httptest.NewRequest(http.MethodPost, "/x", nil))
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/x", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("first chat: got %d, want 200", w.Code)
+	}
+	if inner == nil || inner.Code != http.StatusTooManyRequests {
+		t.Fatalf("concurrent chat should get 429, got %+v", inner)
+	}
+	if body := decodeQuotaError(t, inner); body.Error.Details["limit"] != "concurrent_chats" {
+		t.Fatalf("unexpected error body: %s", inner.Body.String())
+	}
+
+	// The slot is released when the first chat ends.
+	inner = nil
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/x", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("chat after release: got %d, want 200", w.Code)
+	}
+}
+
+func TestTenantQuotasUploads(t *testing.T) {
+	q := NewTenantQuotas(&config.Config{RateLimit: &config.RateLimitConfig{DailyUploads: 1}}, nil)
+
+	full := newQuotaRouter(&types.Tenant{ID: 9, StorageQuota: 100, StorageUsed: 90}, q.Uploads(), okHandler)
+	w := httptest.NewRecorder()
+	full.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/x", strings.NewReader(strings.Repeat("a", 20))))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("upload over storage quota: got %d, want 429", w.Code)
+	}
+	if body := decodeQuotaError(t, w); body.Error.Code != apperrors.ErrTenantStorageQuota {
+		t.Fatalf("unexpected error body: %s", w.Body.String())
+	}
+
+	r := newQuotaRouter(&types.Tenant{ID: 7, StorageQuota: 100}, q.Uploads(), okHandler)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/x", strings.NewReader("doc")))
+	if w.Code != http.StatusOK {
+		t.Fatalf("first upload: got %d, want 200", w.Code)
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/x", strings.NewReader("doc")))
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second upload: got %d, want 429", w.Code)
+	}
+	if body := decodeQuotaError(t, w); body.Error.Details["limit"] != "daily_uploads" {
+		t.Fatalf("unexpected error body: %s", w.Body.String())
+	}
+}
@@ -6,16 +6,20 @@ import (
 	"github.com/Tencent/WeKnora/internal/types"
 )
 
-// Segment represents a transcribed segment with timestamps.
+// Segment represents a transcribed segment with timestamps in seconds.
+// Speaker is set by servers with speaker diarization (e.g. WhisperX).
 type Segment struct {
-	Start float64 `json:"start"`
-	End   float64 `json:"end"`
-	Text  string  `json:"text"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+	Text    string  `json:"text"`
+	Speaker string  `json:"speaker,omitempty"`
 }
 
 // TranscriptionResult holds the full text and its segments.
 type TranscriptionResult struct {
 	Text     string    `json:"text"`
+	Language string    `json:"language,omitempty"`
+	Duration float64   `json:"duration,omitempty"` // length of the audio in seconds
 	Segments []Segment `json:"segments,omitempty"`
 }
 
@@ -5,6 +5,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
 	"path/filepath"
 	"strings"
@@ -12,47 +14,63 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/logger"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
-	openai "github.com/sashabaranov/go-openai"
 )
 
 const (
 	asrDefaultTimeout = 300 * time.Second // audio transcription can be slow
+	asrDefaultBaseURL = "https://api.openai.com/v1"
 )
 
 // OpenAIASR implements ASR via an OpenAI-compatible audio transcriptions API.
 type OpenAIASR struct {
-	modelName string
-	modelID   string
-	client    *openai.Client
-	baseURL   string
-	language  string
+	modelName  string
+	modelID    string
+	apiKey     string
+	httpClient *http.Client
+	baseURL    string
+	language   string
+}
+
+// transcriptionResponse is the verbose_json answer of the transcriptions API.
+// Servers that only return text leave the other fields empty; servers with
+// speaker diarization add a speaker to each segment.
+type transcriptionResponse struct {
+	Text     string  `json:"text"`
+	Language string  `json:"language"`
+	Duration float64 `json:"duration"`
+	Segments []struct {
+		Start   float64 `json:"start"`
+		End     float64 `json:"end"`
+		Text    string  `json:"text"`
+		Speaker string  `json:"speaker"`
+	} `json:"segments"`
 }
 
 // NewOpenAIASR creates an OpenAI-compatible ASR instance.
 func NewOpenAIASR(config *Config) (*OpenAIASR, error) {
-	apiCfg := openai.DefaultConfig(config.APIKey)
-	if config.BaseURL != "" {
-		apiCfg.BaseURL = config.BaseURL
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = asrDefaultBaseURL
 	}
 	httpClient := &http.Client{Timeout: asrDefaultTimeout}
 
 	// 注入用户自定义 HTTP header（类似 OpenAI Python SDK 的 extra_headers）
 	if len(config.CustomHeaders) > 0 {
-		apiCfg.HTTPClient = secutils.WrapHTTPClientWithHeaders(httpClient, config.CustomHeaders)
-	} else {
-		apiCfg.HTTPClient = httpClient
+		httpClient = secutils.WrapHTTPClientWithHeaders(httpClient, config.CustomHeaders)
 	}
 
 	return &OpenAIASR{
-		modelName: config.ModelName,
-		modelID:   config.ModelID,
-		client:    openai.NewClientWithConfig(apiCfg),
-		baseURL:   config.BaseURL,
-		language:  config.Language,
+		modelName:  config.ModelName,
+		modelID:    config.ModelID,
+		apiKey:     config.APIKey,
+		httpClient: httpClient,
+		baseURL:    baseURL,
+		language:   config.Language,
 	}, nil
 }
 
 // Transcribe sends audio bytes to the OpenAI-compatible audio transcriptions API.
+// Audio tracks of mp4/webm videos are accepted as well.
 func (s *OpenAIASR) Transcribe(ctx context.Context, audioBytes []byte, fileName string) (*TranscriptionResult, error) {
 	if len(audioBytes) == 0 {
 		return nil, fmt.Errorf("audio bytes are empty")
@@ -66,46 +84,91 @@ func (s *OpenAIASR) Transcribe(ctx context.Context, audioBytes []byte, fileName
 	logger.Infof(ctx, "[ASR] Calling OpenAI-compatible transcription API, model=%s, baseURL=%s, audioSize=%d, file=%s",
 		s.modelName, s.baseURL, len(audioBytes), fileName)
 
-	req := openai.AudioRequest{
-		Model:    s.modelName,
-		FilePath: fileName,
-		Reader:   bytes.NewReader(audioBytes),
-		Format:   openai.AudioResponseFormatVerboseJSON,
+	body, contentType, err := s.buildTranscriptionForm(audioBytes, fileName)
+	if err != nil {
+		return nil, fmt.Errorf("build transcription request: %w", err)
 	}
-
-	if s.language != "" {
-		req.Language = s.language
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/audio/transcriptions", body)
+	if err != nil {
+		return nil, fmt.Errorf("build transcription request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
 	}
 
-	resp, err := s.client.CreateTranscription(ctx, req)
+	httpResp, err := s.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("ASR transcription request failed: %w", err)
 	}
-
-	jsonData, err := json.Marshal(resp)
+	defer httpResp.Body.Close()
+	respBody, err := io.ReadAll(httpResp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("marshal transcription response: %w", err)
+		return nil, fmt.Errorf("ASR transcription request failed: read response: %w", err)
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return nil, fmt.Errorf("ASR transcription request failed: status code: %d, body: %s",
+			httpResp.StatusCode, secutils.SanitizeForLog(string(respBody)))
+	}
+	logger.Debugf(ctx, "[ASR] Transcription response: %s", string(respBody))
+
+	var resp transcriptionResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("decode transcription response: %w", err)
 	}
-	logger.Debugf(ctx, "[ASR] Transcription response: %s", string(jsonData))
 
 	text := strings.TrimSpace(resp.Text)
-	logger.Infof(ctx, "[ASR] Transcription completed, text length=%d", len(text))
+	logger.Infof(ctx, "[ASR] Transcription completed, text length=%d, segments=%d", len(text), len(resp.Segments))
 
 	var segments []Segment
 	for _, s := range resp.Segments {
 		segments = append(segments, Segment{
-			Start: s.Start,
-			End:   s.End,
-			Text:  strings.TrimSpace(s.Text),
+			Start:   s.Start,
+			End:     s.End,
+			Text:    strings.TrimSpace(s.Text),
+			Speaker: s.Speaker,
 		})
 	}
 
 	return &TranscriptionResult{
 		Text:     text,
+		Language: resp.Language,
+		Duration: resp.Duration,
 		Segments: segments,
 	}, nil
 }
 
+// buildTranscriptionForm builds the multipart body of a transcription request,
+// asking for segment timestamps.
+func (s *OpenAIASR) buildTranscriptionForm(audioBytes []byte, fileName string) (io.Reader, string, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, err := w.CreateFormFile("file", filepath.Base(fileName))
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(audioBytes); err != nil {
+		return nil, "", err
+	}
+	fields := [][2]string{
+		{"model", s.modelName},
+		{"response_format", "verbose_json"},
+		{"timestamp_granularities[]", "segment"},
+	}
+	if s.language != "" {
+		fields = append(fields, [2]string{"language", s.language})
+	}
+	for _, f := range fields {
+		if err := w.WriteField(f[0], f[1]); err != nil {
+			return nil, "", err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", err
+	}
+	return &buf, w.FormDataContentType(), nil
+}
+
 func (s *OpenAIASR) GetModelName() string { return s.modelName }
 func (s *OpenAIASR) GetModelID() string   { return s.modelID }
 
@@ -0,0 +1,74 @@
+package asr
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenAIASRTranscribe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audio/transcriptions" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk" {
+			t.Errorf("unexpected Authorization %q", got)
+		}
+		if got := r.Header.Get("X-Trace"); got != "1" {
+			t.Errorf("custom header not sent, got %q", got)
+		}
+		if r.FormValue("response_format") != "verbose_json" || r.FormValue("model") != "whisper-1" ||
+			r.FormValue("language") != "zh" {
+			t.Errorf("unexpected form %v", r.MultipartForm.Value)
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Fatalf("file missing: %v", err)
+		}
+		data, _ := io.ReadAll(file)
+		if header.Filename != "meeting.mp4" || string(data) != "audio" {
+			t.Errorf("unexpected file %s: %q", header.Filename, data)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"text":" hi there ","language":"zh","duration":3.5,"segments":[
+			{"start":0,"end":1.5,"text":" hi ","speaker":"SPEAKER_00"},
+			{"start":1.5,"end":3.5,"text":"there"}]}`))
+	}))
+	defer server.Close()
+
+	a, err := NewOpenAIASR(&Config{
+		BaseURL:       server.URL + "/v1/",
+		ModelName:     "whisper-1",
+		APIKey:        "sk",
+		Language:      "zh",
+		CustomHeaders: map[string]string{"X-Trace": "1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := a.Transcribe(context.Background(), []byte("audio"), "meeting.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Text != "hi there" || result.Duration != 3.5 || len(result.Segments) != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if s := result.Segments[0]; s.Text != "hi" || s.Speaker != "SPEAKER_00" || s.End != 1.5 {
+		t.Errorf("unexpected segment %+v", s)
+	}
+}
+
+func TestOpenAIASRTranscribeError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error":{"message":"bad key"}}`))
+	}))
+	defer server.Close()
+
+	a, _ := NewOpenAIASR(&Config{BaseURL: server.URL, ModelName: "whisper-1"})
+	if _, err := a.Transcribe(context.Background(), []byte("audio"), "a.mp3"); err == nil {
+		t.Fatal("expected an error for a 401 answer")
+	}
+}
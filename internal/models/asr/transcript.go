@@ -0,0 +1,95 @@
+package asr
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultChunkSeconds is the longest stretch of audio a transcript chunk
+	// covers by default.
+	DefaultChunkSeconds = 60
+	// DefaultChunkChars is the most characters a transcript chunk holds by
+	// default.
+	DefaultChunkChars = 1000
+)
+
+// ChunkOptions bounds the transcript chunks built by ChunkTranscript.
+type ChunkOptions struct {
+	MaxSeconds float64 // defaults to DefaultChunkSeconds
+	MaxChars   int     // defaults to DefaultChunkChars
+}
+
+// TranscriptChunk is a run of consecutive segments of one speaker.
+type TranscriptChunk struct {
+	Start   float64 // seconds from the start of the audio
+	End     float64
+	Speaker string
+	Text    string
+}
+
+// Content renders the chunk for indexing, prefixed with its time range and
+// speaker so that answers can point at the moment in the recording.
+func (c TranscriptChunk) Content() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s - %s] ", FormatTimestamp(c.Start), FormatTimestamp(c.End))
+	if c.Speaker != "" {
+		b.WriteString(c.Speaker)
+		b.WriteString(": ")
+	}
+	b.WriteString(c.Text)
+	return b.String()
+}
+
+// ChunkTranscript groups the segments of a transcription into chunks. A new
+// chunk starts when the speaker changes, or when adding the next segment
+// would make the chunk longer than MaxSeconds or MaxChars; a single segment
+// over the limits forms a chunk of its own. A transcription without
+// segments yields no chunks; its text is split like any other document.
+func ChunkTranscript(result *TranscriptionResult, opts ChunkOptions) []TranscriptChunk {
+	if result == nil {
+		return nil
+	}
+	if opts.MaxSeconds <= 0 {
+		opts.MaxSeconds = DefaultChunkSeconds
+	}
+	if opts.MaxChars <= 0 {
+		opts.MaxChars = DefaultChunkChars
+	}
+
+	var chunks []TranscriptChunk
+	var current *TranscriptChunk
+	for _, seg := range result.Segments {
+		text := strings.TrimSpace(seg.Text)
+		if text == "" {
+			continue
+		}
+		if current != nil && (seg.Speaker != current.Speaker ||
+			seg.End-current.Start > opts.MaxSeconds ||
+			utf8.RuneCountInString(current.Text)+1+utf8.RuneCountInString(text) > opts.MaxChars) {
+			chunks = append(chunks, *current)
+			current = nil
+		}
+		if current == nil {
+			current = &TranscriptChunk{Start: seg.Start, End: seg.End, Speaker: seg.Speaker, Text: text}
+			continue
+		}
+		current.End = seg.End
+		current.Text += " " + text
+	}
+	if current != nil {
+		chunks = append(chunks, *current)
+	}
+	return chunks
+}
+
+// FormatTimestamp formats seconds as mm:ss, or hh:mm:ss from one hour on.
+func FormatTimestamp(seconds float64) string {
+	total := int(max(seconds, 0))
+	h, m, s := total/3600, total%3600/60, total%60
+	if h > 0 {
+		return fmt.Sprintf("%02d:%02d:%02d", h, m, s)
+	}
+	return fmt.Sprintf("%02d:%02d", m, s)
+}
@@ -0,0 +1,63 @@
+package asr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChunkTranscript(t *testing.T) {
+	result := &TranscriptionResult{Segments: []Segment{
+		{Start: 0, End: 4, Text: "Welcome to the call.", Speaker: "A"},
+		{Start: 4, End: 9, Text: "Today we review the budget.", Speaker: "A"},
+		{Start: 9, End: 12, Text: "Sounds good.", Speaker: "B"},
+		{Start: 12, End: 13, Text: "  "},
+		{Start: 13, End: 40, Text: "First item.", Speaker: "B"},
+		{Start: 40, End: 80, Text: "Second item.", Speaker: "B"},
+	}}
+
+	chunks := ChunkTranscript(result, ChunkOptions{MaxSeconds: 60})
+
+	want := []TranscriptChunk{
+		{Start: 0, End: 9, Speaker: "A", Text: "Welcome to the call. Today we review the budget."},
+		// A speaker change starts a new chunk, and so does the time window.
+		{Start: 9, End: 40, Speaker: "B", Text: "Sounds good. First item."},
+		{Start: 40, End: 80, Speaker: "B", Text: "Second item."},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks, want %d: %+v", len(chunks), len(want), chunks)
+	}
+	for i := range want {
+		if chunks[i] != want[i] {
+			t.Errorf("chunk %d: got %+v, want %+v", i, chunks[i], want[i])
+		}
+	}
+}
+
+func TestChunkTranscriptMaxChars(t *testing.T) {
+	result := &TranscriptionResult{Segments: []Segment{
+		{Start: 0, End: 1, Text: strings.Repeat("a", 6)},
+		{Start: 1, End: 2, Text: strings.Repeat("b", 6)},
+		{Start: 2, End: 3, Text: strings.Repeat("c", 20)},
+	}}
+	chunks := ChunkTranscript(result, ChunkOptions{MaxChars: 13})
+	if len(chunks) != 2 || chunks[0].Text != "aaaaaa bbbbbb" || chunks[1].Start != 2 {
+		t.Fatalf("unexpected chunks: %+v", chunks)
+	}
+}
+
+func TestChunkTranscriptWithoutSegments(t *testing.T) {
+	if chunks := ChunkTranscript(&TranscriptionResult{Text: "plain text"}, ChunkOptions{}); chunks != nil {
+		t.Fatalf("expected no chunks, got %+v", chunks)
+	}
+}
+
+func TestTranscriptChunkContent(t *testing.T) {
+	c := TranscriptChunk{Start: 83, End: 3725, Speaker: "SPEAKER_01", Text: "hello"}
+	if got := c.Content(); got != "[01:23 - 01:02:05] SPEAKER_01: hello" {
+		t.Fatalf("got %q", got)
+	}
+	c.Speaker = ""
+	if got := c.Content(); got != "[01:23 - 01:02:05] hello" {
+		t.Fatalf("got %q", got)
+	}
+}
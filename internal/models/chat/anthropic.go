@@ -25,30 +25,97 @@ type AnthropicChat struct {
 	customHeaders map[string]string
 }
 
+// anthropicStructuredOutputTool is the tool a JSON schema response format is
+// forced through: the Messages API returns structured output as the input of
+// a tool the model is required to call.
+const anthropicStructuredOutputTool = "structured_output"
+
+// anthropicMessage is a turn of the conversation. Plain text turns are sent
+// with a string content; turns with images, tool calls or tool results are
+// sent as content blocks.
 type anthropicMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string
+	Content string
+	Blocks  []anthropicContentBlock
+}
+
+func (m anthropicMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Blocks) > 0 {
+		return json.Marshal(struct {
+			Role    string                  `json:"role"`
+			Content []anthropicContentBlock `json:"content"`
+		}{m.Role, m.Blocks})
+	}
+	return json.Marshal(struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	}{m.Role, m.Content})
+}
+
+func (m *anthropicMessage) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	m.Role = raw.Role
+	if len(raw.Content) > 0 && raw.Content[0] == '[' {
+		return json.Unmarshal(raw.Content, &m.Blocks)
+	}
+	return json.Unmarshal(raw.Content, &m.Content)
+}
+
+// anthropicContentBlock is a text, image, tool_use or tool_result block.
+type anthropicContentBlock struct {
+	Type      string                `json:"type"`
+	Text      string                `json:"text,omitempty"`
+	Source    *anthropicImageSource `json:"source,omitempty"`
+	ID        string                `json:"id,omitempty"`
+	Name      string                `json:"name,omitempty"`
+	Input     json.RawMessage       `json:"input,omitempty"`
+	ToolUseID string                `json:"tool_use_id,omitempty"`
+	Content   string                `json:"content,omitempty"`
+}
+
+type anthropicImageSource struct {
+	Type      string `json:"type"` // "base64" or "url"
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+}
+
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+type anthropicToolChoice struct {
+	Type                   string `json:"type"` // "auto", "any", "tool" or "none"
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
 }
 
 type anthropicRequest struct {
-	Model       string             `json:"model"`
-	MaxTokens   int                `json:"max_tokens"`
-	Stream      bool               `json:"stream,omitempty"`
-	System      string             `json:"system,omitempty"`
-	Messages    []anthropicMessage `json:"messages"`
-	Temperature *float64           `json:"temperature,omitempty"`
-	TopP        *float64           `json:"top_p,omitempty"`
+	Model       string               `json:"model"`
+	MaxTokens   int                  `json:"max_tokens"`
+	Stream      bool                 `json:"stream,omitempty"`
+	System      string               `json:"system,omitempty"`
+	Messages    []anthropicMessage   `json:"messages"`
+	Temperature *float64             `json:"temperature,omitempty"`
+	TopP        *float64             `json:"top_p,omitempty"`
+	Tools       []anthropicTool      `json:"tools,omitempty"`
+	ToolChoice  *anthropicToolChoice `json:"tool_choice,omitempty"`
 }
 
 type anthropicResponse struct {
-	ID      string `json:"id"`
-	Type    string `json:"type"`
-	Role    string `json:"role"`
-	Content []struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
-	} `json:"content"`
-	StopReason string `json:"stop_reason"`
+	ID         string                  `json:"id"`
+	Type       string                  `json:"type"`
+	Role       string                  `json:"role"`
+	Content    []anthropicContentBlock `json:"content"`
+	StopReason string                  `json:"stop_reason"`
 	Usage      struct {
 		InputTokens  int `json:"input_tokens"`
 		OutputTokens int `json:"output_tokens"`
@@ -61,6 +128,7 @@ type anthropicResponse struct {
 
 type anthropicStreamEvent struct {
 	Type    string `json:"type"`
+	Index   int    `json:"index"`
 	Message *struct {
 		Usage struct {
 			InputTokens              int `json:"input_tokens"`
@@ -69,10 +137,12 @@ type anthropicStreamEvent struct {
 			CacheReadInputTokens     int `json:"cache_read_input_tokens"`
 		} `json:"usage"`
 	} `json:"message,omitempty"`
-	Delta *struct {
-		Type       string `json:"type"`
-		Text       string `json:"text"`
-		StopReason string `json:"stop_reason"`
+	ContentBlock *anthropicContentBlock `json:"content_block,omitempty"`
+	Delta        *struct {
+		Type        string `json:"type"`
+		Text        string `json:"text"`
+		PartialJSON string `json:"partial_json"`
+		StopReason  string `json:"stop_reason"`
 	} `json:"delta,omitempty"`
 	Usage *struct {
 		InputTokens              int `json:"input_tokens"`
@@ -268,6 +338,7 @@ func (c *AnthropicChat) buildRequest(messages []Message, opts *ChatOptions) anth
 			topP := opts.TopP
 			req.TopP = &topP
 		}
+		req.Tools, req.ToolChoice = anthropicTools(opts)
 	}
 
 	var systemParts []string
@@ -276,24 +347,148 @@ func (c *AnthropicChat) buildRequest(messages []Message, opts *ChatOptions) anth
 		if content == "" {
 			content = textFromMultiContent(msg.MultiContent)
 		}
-		if content == "" {
-			continue
-		}
 		switch msg.Role {
 		case "system":
-			systemParts = append(systemParts, content)
+			if content != "" {
+				systemParts = append(systemParts, content)
+			}
 		case "assistant":
-			req.Messages = append(req.Messages, anthropicMessage{Role: "assistant", Content: content})
-		case "user":
-			req.Messages = append(req.Messages, anthropicMessage{Role: "user", Content: content})
+			blocks := anthropicToolUseBlocks(msg.ToolCalls)
+			if len(blocks) == 0 {
+				if content != "" {
+					req.Messages = append(req.Messages, anthropicMessage{Role: "assistant", Content: content})
+				}
+				continue
+			}
+			if content != "" {
+				blocks = append([]anthropicContentBlock{{Type: "text", Text: content}}, blocks...)
+			}
+			req.Messages = append(req.Messages, anthropicMessage{Role: "assistant", Blocks: blocks})
+		case "tool":
+			// Results of the tool calls of one assistant turn go back together
+			// in the next user turn.
+			result := anthropicContentBlock{Type: "tool_result", ToolUseID: msg.ToolCallID, Content: content}
+			if n := len(req.Messages); n > 0 && isAnthropicToolResultTurn(req.Messages[n-1]) {
+				req.Messages[n-1].Blocks = append(req.Messages[n-1].Blocks, result)
+				continue
+			}
+			req.Messages = append(req.Messages, anthropicMessage{Role: "user", Blocks: []anthropicContentBlock{result}})
 		default:
-			req.Messages = append(req.Messages, anthropicMessage{Role: "user", Content: content})
+			blocks := anthropicImageBlocks(msg)
+			if len(blocks) == 0 {
+				if content != "" {
+					req.Messages = append(req.Messages, anthropicMessage{Role: "user", Content: content})
+				}
+				continue
+			}
+			if content != "" {
+				blocks = append(blocks, anthropicContentBlock{Type: "text", Text: content})
+			}
+			req.Messages = append(req.Messages, anthropicMessage{Role: "user", Blocks: blocks})
 		}
 	}
 	req.System = strings.Join(systemParts, "\n\n")
 	return req
 }
 
+// anthropicTools maps the tools of opts, or its JSON schema response format,
+// to the tool definitions and tool choice of a Messages request.
+func anthropicTools(opts *ChatOptions) ([]anthropicTool, *anthropicToolChoice) {
+	if len(opts.Tools) == 0 {
+		if len(opts.Format) == 0 {
+			return nil, nil
+		}
+		return []anthropicTool{{
+			Name:        anthropicStructuredOutputTool,
+			Description: "Respond with a JSON object matching this schema.",
+			InputSchema: opts.Format,
+		}}, &anthropicToolChoice{
+			Type: "tool",
+			Name: anthropicStructuredOutputTool,
+		}
+	}
+
+	tools := make([]anthropicTool, 0, len(opts.Tools))
+	for _, tool := range opts.Tools {
+		schema := tool.Function.Parameters
+		if len(schema) == 0 {
+			schema = json.RawMessage(`{"type":"object","properties":{}}`)
+		}
+		tools = append(tools, anthropicTool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			InputSchema: schema,
+		})
+	}
+
+	var choice *anthropicToolChoice
+	switch opts.ToolChoice {
+	case "", "auto":
+		choice = &anthropicToolChoice{Type: "auto"}
+	case "required":
+		choice = &anthropicToolChoice{Type: "any"}
+	case "none":
+		return tools, &anthropicToolChoice{Type: "none"}
+	default:
+		choice = &anthropicToolChoice{Type: "tool", Name: opts.ToolChoice}
+	}
+	if opts.ParallelToolCalls != nil && !*opts.ParallelToolCalls {
+		choice.DisableParallelToolUse = true
+	} else if choice.Type == "auto" {
+		choice = nil
+	}
+	return tools, choice
+}
+
+func anthropicToolUseBlocks(toolCalls []ToolCall) []anthropicContentBlock {
+	blocks := make([]anthropicContentBlock, 0, len(toolCalls))
+	for _, tc := range toolCalls {
+		input := json.RawMessage(tc.Function.Arguments)
+		if !json.Valid(input) {
+			input = json.RawMessage("{}")
+		}
+		blocks = append(blocks, anthropicContentBlock{
+			Type:  "tool_use",
+			ID:    tc.ID,
+			Name:  tc.Function.Name,
+			Input: input,
+		})
+	}
+	return blocks
+}
+
+func isAnthropicToolResultTurn(msg anthropicMessage) bool {
+	return msg.Role == "user" && len(msg.Blocks) > 0 && msg.Blocks[0].Type == "tool_result"
+}
+
+// anthropicImageBlocks returns the image blocks of a user message, taken
+// from its multi-content parts or its attached images.
+func anthropicImageBlocks(msg Message) []anthropicContentBlock {
+	var urls []string
+	for _, part := range msg.MultiContent {
+		if part.Type == "image_url" && part.ImageURL != nil {
+			urls = append(urls, part.ImageURL.URL)
+		}
+	}
+	if len(msg.MultiContent) == 0 {
+		urls = msg.Images
+	}
+	blocks := make([]anthropicContentBlock, 0, len(urls))
+	for _, u := range urls {
+		resolved := resolveImageURLForLLM(u)
+		if mimeType, data, ok := splitDataURI(resolved); ok {
+			blocks = append(blocks, anthropicContentBlock{Type: "image", Source: &anthropicImageSource{
+				Type: "base64", MediaType: mimeType, Data: data,
+			}})
+		} else if strings.HasPrefix(resolved, "http://") || strings.HasPrefix(resolved, "https://") {
+			blocks = append(blocks, anthropicContentBlock{Type: "image", Source: &anthropicImageSource{
+				Type: "url", URL: resolved,
+			}})
+		}
+	}
+	return blocks
+}
+
 func textFromMultiContent(parts []MessageContentPart) string {
 	if len(parts) == 0 {
 		return ""
@@ -309,16 +504,27 @@ func textFromMultiContent(parts []MessageContentPart) string {
 
 func (c *AnthropicChat) parseResponse(resp *anthropicResponse) *types.ChatResponse {
 	parts := make([]string, 0, len(resp.Content))
+	var toolCalls []types.LLMToolCall
 	for _, part := range resp.Content {
-		if part.Type == "text" && part.Text != "" {
+		switch {
+		case part.Type == "text" && part.Text != "":
 			parts = append(parts, part.Text)
+		case part.Type == "tool_use" && part.Name == anthropicStructuredOutputTool:
+			parts = append(parts, string(part.Input))
+		case part.Type == "tool_use":
+			toolCalls = append(toolCalls, types.LLMToolCall{
+				ID:       part.ID,
+				Type:     "function",
+				Function: types.FunctionCall{Name: part.Name, Arguments: string(part.Input)},
+			})
 		}
 	}
 	inputTokens := resp.Usage.InputTokens
 	outputTokens := resp.Usage.OutputTokens
 	return &types.ChatResponse{
 		Content:      strings.Join(parts, ""),
-		FinishReason: resp.StopReason,
+		ToolCalls:    toolCalls,
+		FinishReason: anthropicFinishReason(resp.StopReason, len(toolCalls) > 0),
 		Usage: types.TokenUsage{
 			PromptTokens:     inputTokens,
 			CompletionTokens: outputTokens,
@@ -327,12 +533,107 @@ func (c *AnthropicChat) parseResponse(resp *anthropicResponse) *types.ChatRespon
 	}
 }
 
+// anthropicFinishReason maps a Messages API stop reason to the OpenAI finish
+// reason callers branch on. A tool_use stop without tool calls is the end of
+// a structured output answer.
+func anthropicFinishReason(stopReason string, hasToolCalls bool) string {
+	switch stopReason {
+	case "end_turn", "stop_sequence", "pause_turn":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "tool_use":
+		if hasToolCalls {
+			return "tool_calls"
+		}
+		return "stop"
+	case "refusal":
+		return "content_filter"
+	default:
+		return stopReason
+	}
+}
+
+// anthropicStreamState accumulates the answer of a Messages API event stream.
+type anthropicStreamState struct {
+	toolCalls  map[int]*types.LLMToolCall
+	toolOrder  []int
+	structured map[int]bool
+	usage      *types.TokenUsage
+	stopReason string
+}
+
+func newAnthropicStreamState() *anthropicStreamState {
+	return &anthropicStreamState{
+		toolCalls:  make(map[int]*types.LLMToolCall),
+		structured: make(map[int]bool),
+	}
+}
+
+// apply folds an event into the state. It returns the answer text the event
+// carries, and the tool call it starts, if any.
+func (s *anthropicStreamState) apply(event *anthropicStreamEvent) (string, *types.LLMToolCall) {
+	if event.Message != nil {
+		s.usage = mergeAnthropicUsage(s.usage, event.Message.Usage.InputTokens, event.Message.Usage.OutputTokens)
+	}
+	if event.Usage != nil {
+		s.usage = mergeAnthropicUsage(s.usage, event.Usage.InputTokens, event.Usage.OutputTokens)
+	}
+	if block := event.ContentBlock; block != nil && block.Type == "tool_use" {
+		if block.Name == anthropicStructuredOutputTool {
+			s.structured[event.Index] = true
+			return "", nil
+		}
+		call := &types.LLMToolCall{ID: block.ID, Type: "function", Function: types.FunctionCall{Name: block.Name}}
+		s.toolCalls[event.Index] = call
+		s.toolOrder = append(s.toolOrder, event.Index)
+		return "", call
+	}
+	if event.Delta == nil {
+		return "", nil
+	}
+	if event.Delta.StopReason != "" {
+		s.stopReason = event.Delta.StopReason
+	}
+	switch event.Delta.Type {
+	case "text_delta":
+		return event.Delta.Text, nil
+	case "input_json_delta":
+		if s.structured[event.Index] {
+			return event.Delta.PartialJSON, nil
+		}
+		if call, ok := s.toolCalls[event.Index]; ok {
+			call.Function.Arguments += event.Delta.PartialJSON
+		}
+	}
+	return "", nil
+}
+
+// orderedToolCalls returns the tool calls of the stream in order, with the
+// empty arguments of a tool without parameters sent as an empty object.
+func (s *anthropicStreamState) orderedToolCalls() []types.LLMToolCall {
+	if len(s.toolOrder) == 0 {
+		return nil
+	}
+	calls := make([]types.LLMToolCall, 0, len(s.toolOrder))
+	for _, idx := range s.toolOrder {
+		call := *s.toolCalls[idx]
+		if call.Function.Arguments == "" {
+			call.Function.Arguments = "{}"
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+func (s *anthropicStreamState) finishReason() string {
+	return anthropicFinishReason(s.stopReason, len(s.toolOrder) > 0)
+}
+
 func parseAnthropicSSE(reader io.Reader) (*types.ChatResponse, error) {
 	sseReader := NewSSEReader(reader)
+	state := newAnthropicStreamState()
 	var contentParts []string
-	var finishReason string
-	var inputTokens int
-	var outputTokens int
 
 	for {
 		event, err := sseReader.ReadEvent()
@@ -356,33 +657,20 @@ func parseAnthropicSSE(reader io.Reader) (*types.ChatResponse, error) {
 		if streamEvent.Error != nil && streamEvent.Error.Message != "" {
 			return nil, fmt.Errorf("API stream error: %s", streamEvent.Error.Message)
 		}
-		if streamEvent.Message != nil {
-			inputTokens = max(inputTokens, streamEvent.Message.Usage.InputTokens)
-			outputTokens = max(outputTokens, streamEvent.Message.Usage.OutputTokens)
-		}
-		if streamEvent.Delta != nil {
-			if streamEvent.Delta.Type == "text_delta" && streamEvent.Delta.Text != "" {
-				contentParts = append(contentParts, streamEvent.Delta.Text)
-			}
-			if streamEvent.Delta.StopReason != "" {
-				finishReason = streamEvent.Delta.StopReason
-			}
-		}
-		if streamEvent.Usage != nil {
-			inputTokens = max(inputTokens, streamEvent.Usage.InputTokens)
-			outputTokens = max(outputTokens, streamEvent.Usage.OutputTokens)
+		if text, _ := state.apply(&streamEvent); text != "" {
+			contentParts = append(contentParts, text)
 		}
 	}
 
-	return &types.ChatResponse{
+	resp := &types.ChatResponse{
 		Content:      strings.Join(contentParts, ""),
-		FinishReason: finishReason,
-		Usage: types.TokenUsage{
-			PromptTokens:     inputTokens,
-			CompletionTokens: outputTokens,
-			TotalTokens:      inputTokens + outputTokens,
-		},
-	}, nil
+		ToolCalls:    state.orderedToolCalls(),
+		FinishReason: state.finishReason(),
+	}
+	if state.usage != nil {
+		resp.Usage = *state.usage
+	}
+	return resp, nil
 }
 
 func processAnthropicStream(ctx context.Context, model string, resp *http.Response, streamChan chan types.StreamResponse) {
@@ -390,21 +678,24 @@ func processAnthropicStream(ctx context.Context, model string, resp *http.Respon
 	defer resp.Body.Close()
 
 	sseReader := NewSSEReader(resp.Body)
-	var usage *types.TokenUsage
-	var finishReason string
+	state := newAnthropicStreamState()
+	finish := func() {
+		logUsage(ctx, model, state.usage)
+		streamChan <- types.StreamResponse{
+			ResponseType: types.ResponseTypeAnswer,
+			Content:      "",
+			Done:         true,
+			ToolCalls:    state.orderedToolCalls(),
+			Usage:        state.usage,
+			FinishReason: state.finishReason(),
+		}
+	}
 
 	for {
 		event, err := sseReader.ReadEvent()
 		if err != nil {
 			if err == io.EOF {
-				logUsage(ctx, model, usage)
-				streamChan <- types.StreamResponse{
-					ResponseType: types.ResponseTypeAnswer,
-					Content:      "",
-					Done:         true,
-					Usage:        usage,
-					FinishReason: finishReason,
-				}
+				finish()
 			} else {
 				streamChan <- types.StreamResponse{
 					ResponseType: types.ResponseTypeError,
@@ -415,14 +706,7 @@ func processAnthropicStream(ctx context.Context, model string, resp *http.Respon
 			return
 		}
 		if event.Done {
-			logUsage(ctx, model, usage)
-			streamChan <- types.StreamResponse{
-				ResponseType: types.ResponseTypeAnswer,
-				Content:      "",
-				Done:         true,
-				Usage:        usage,
-				FinishReason: finishReason,
-			}
+			finish()
 			return
 		}
 		if len(event.Data) == 0 {
@@ -446,23 +730,24 @@ func processAnthropicStream(ctx context.Context, model string, resp *http.Respon
 			}
 			return
 		}
-		if streamEvent.Message != nil {
-			usage = mergeAnthropicUsage(usage, streamEvent.Message.Usage.InputTokens, streamEvent.Message.Usage.OutputTokens)
-		}
-		if streamEvent.Delta != nil {
-			if streamEvent.Delta.StopReason != "" {
-				finishReason = streamEvent.Delta.StopReason
-			}
-			if streamEvent.Delta.Type == "text_delta" && streamEvent.Delta.Text != "" {
-				streamChan <- types.StreamResponse{
-					ResponseType: types.ResponseTypeAnswer,
-					Content:      streamEvent.Delta.Text,
-					Done:         false,
-				}
+		text, call := state.apply(&streamEvent)
+		if call != nil {
+			streamChan <- types.StreamResponse{
+				ResponseType: types.ResponseTypeToolCall,
+				Content:      "",
+				Done:         false,
+				Data: map[string]interface{}{
+					"tool_name":    call.Function.Name,
+					"tool_call_id": call.ID,
+				},
 			}
 		}
-		if streamEvent.Usage != nil {
-			usage = mergeAnthropicUsage(usage, streamEvent.Usage.InputTokens, streamEvent.Usage.OutputTokens)
+		if text != "" {
+			streamChan <- types.StreamResponse{
+				ResponseType: types.ResponseTypeAnswer,
+				Content:      text,
+				Done:         false,
+			}
 		}
 	}
 }
@@ -64,7 +64,7 @@ func TestAnthropicChat(t *testing.T) {
 	assert.Equal(t, "user", capturedRequest.Messages[0].Role)
 	assert.Equal(t, "Hi", capturedRequest.Messages[0].Content)
 	assert.Equal(t, "hello", resp.Content)
-	assert.Equal(t, "end_turn", resp.FinishReason)
+	assert.Equal(t, "stop", resp.FinishReason)
 	assert.Equal(t, 3, resp.Usage.PromptTokens)
 	assert.Equal(t, 2, resp.Usage.CompletionTokens)
 	assert.Equal(t, 5, resp.Usage.TotalTokens)
@@ -173,7 +173,7 @@ data: {"type":"message_stop"}
 	require.NoError(t, err)
 
 	assert.Equal(t, "pong", resp.Content)
-	assert.Equal(t, "end_turn", resp.FinishReason)
+	assert.Equal(t, "stop", resp.FinishReason)
 	assert.Equal(t, 114, resp.Usage.PromptTokens)
 	assert.Equal(t, 5, resp.Usage.CompletionTokens)
 	assert.Equal(t, 119, resp.Usage.TotalTokens)
@@ -226,13 +226,135 @@ data: {"type":"message_stop"}
 	assert.Equal(t, "pong", chunks[0].Content)
 	assert.False(t, chunks[0].Done)
 	assert.True(t, chunks[1].Done)
-	assert.Equal(t, "end_turn", chunks[1].FinishReason)
+	assert.Equal(t, "stop", chunks[1].FinishReason)
 	require.NotNil(t, chunks[1].Usage)
 	assert.Equal(t, 114, chunks[1].Usage.PromptTokens)
 	assert.Equal(t, 5, chunks[1].Usage.CompletionTokens)
 	assert.Equal(t, 119, chunks[1].Usage.TotalTokens)
 }
 
+func TestAnthropicChat_BuildRequestTools(t *testing.T) {
+	chat := &AnthropicChat{modelName: "claude-sonnet-4-5"}
+	noParallel := false
+	req := chat.buildRequest([]Message{
+		{Role: "user", Content: "what is this?", Images: []string{"data:image/png;base64,AAAA"}},
+		{Role: "assistant", ToolCalls: []ToolCall{
+			{ID: "toolu_1", Type: "function", Function: FunctionCall{Name: "search", Arguments: `{"q":"a"}`}},
+			{ID: "toolu_2", Type: "function", Function: FunctionCall{Name: "search"}},
+		}},
+		{Role: "tool", ToolCallID: "toolu_1", Name: "search", Content: "result a"},
+		{Role: "tool", ToolCallID: "toolu_2", Name: "search", Content: "result b"},
+	}, &ChatOptions{
+		Tools:             []Tool{{Type: "function", Function: FunctionDef{Name: "search", Description: "Search"}}},
+		ToolChoice:        "required",
+		ParallelToolCalls: &noParallel,
+	})
+
+	require.Len(t, req.Tools, 1)
+	assert.JSONEq(t, `{"type":"object","properties":{}}`, string(req.Tools[0].InputSchema))
+	assert.Equal(t, &anthropicToolChoice{Type: "any", DisableParallelToolUse: true}, req.ToolChoice)
+
+	require.Len(t, req.Messages, 3)
+	image := req.Messages[0].Blocks[0]
+	assert.Equal(t, "image", image.Type)
+	assert.Equal(t, &anthropicImageSource{Type: "base64", MediaType: "image/png", Data: "AAAA"}, image.Source)
+	assert.Equal(t, "what is this?", req.Messages[0].Blocks[1].Text)
+
+	toolUse := req.Messages[1].Blocks
+	require.Len(t, toolUse, 2)
+	assert.Equal(t, "tool_use", toolUse[0].Type)
+	assert.JSONEq(t, `{"q":"a"}`, string(toolUse[0].Input))
+	assert.JSONEq(t, `{}`, string(toolUse[1].Input))
+
+	results := req.Messages[2]
+	assert.Equal(t, "user", results.Role)
+	require.Len(t, results.Blocks, 2, "tool results of a turn are sent together")
+	assert.Equal(t, "toolu_2", results.Blocks[1].ToolUseID)
+	assert.Equal(t, "result b", results.Blocks[1].Content)
+}
+
+func TestAnthropicChat_StructuredOutput(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+
+	var capturedRequest anthropicRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&capturedRequest))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"content":[{"type":"tool_use","id":"toolu_1","name":"structured_output","input":{"answer":"yes"}}],
+			"stop_reason":"tool_use",
+			"usage":{"input_tokens":3,"output_tokens":2}
+		}`))
+	}))
+	defer server.Close()
+
+	chat, err := NewAnthropicChat(&ChatConfig{BaseURL: server.URL, ModelName: "claude-sonnet-4-5", APIKey: "test-key"})
+	require.NoError(t, err)
+
+	schema := json.RawMessage(`{"type":"object","properties":{"answer":{"type":"string"}}}`)
+	resp, err := chat.Chat(context.Background(), []Message{{Role: "user", Content: "Is it?"}},
+		&ChatOptions{Format: schema})
+	require.NoError(t, err)
+
+	require.Len(t, capturedRequest.Tools, 1)
+	assert.JSONEq(t, string(schema), string(capturedRequest.Tools[0].InputSchema))
+	assert.Equal(t, &anthropicToolChoice{Type: "tool", Name: anthropicStructuredOutputTool}, capturedRequest.ToolChoice)
+	assert.JSONEq(t, `{"answer":"yes"}`, resp.Content)
+	assert.Empty(t, resp.ToolCalls)
+	assert.Equal(t, "stop", resp.FinishReason)
+}
+
+func TestAnthropicChat_ChatStreamToolCalls(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Searching."}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_1","name":"search","input":{}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"q\":"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"leave\"}"}}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":9}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`))
+	}))
+	defer server.Close()
+
+	chat, err := NewAnthropicChat(&ChatConfig{BaseURL: server.URL, ModelName: "claude-sonnet-4-5", APIKey: "test-key"})
+	require.NoError(t, err)
+
+	ch, err := chat.ChatStream(context.Background(), []Message{{Role: "user", Content: "leave?"}},
+		&ChatOptions{Tools: []Tool{{Type: "function", Function: FunctionDef{Name: "search"}}}})
+	require.NoError(t, err)
+
+	var chunks []types.StreamResponse
+	for chunk := range ch {
+		chunks = append(chunks, chunk)
+	}
+
+	require.Len(t, chunks, 3)
+	assert.Equal(t, "Searching.", chunks[0].Content)
+	assert.Equal(t, types.ResponseTypeToolCall, chunks[1].ResponseType)
+	assert.Equal(t, "toolu_1", chunks[1].Data["tool_call_id"])
+	last := chunks[2]
+	assert.True(t, last.Done)
+	assert.Equal(t, "tool_calls", last.FinishReason)
+	require.Len(t, last.ToolCalls, 1)
+	assert.Equal(t, "search", last.ToolCalls[0].Function.Name)
+	assert.JSONEq(t, `{"q":"leave"}`, last.ToolCalls[0].Function.Arguments)
+}
+
 func TestNewRemoteChat_AnthropicProvider(t *testing.T) {
 	chat, err := NewRemoteChat(&ChatConfig{
 		Source:    types.ModelSourceRemote,
@@ -0,0 +1,684 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/models/utils"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+)
+
+// bedrockStructuredOutputTool is the tool a JSON schema response format is
+// forced through: the Converse API has no response format, so structured
+// output comes back as the input of a call to this tool.
+const bedrockStructuredOutputTool = "structured_output"
+
+// BedrockChat talks to Amazon Bedrock through the Converse API.
+type BedrockChat struct {
+	modelName     string
+	modelID       string
+	baseURL       string
+	auth          utils.AWSAuth
+	customHeaders map[string]string
+}
+
+type bedrockMessage struct {
+	Role    string                `json:"role"`
+	Content []bedrockContentBlock `json:"content"`
+}
+
+type bedrockContentBlock struct {
+	Text             string                   `json:"text,omitempty"`
+	Image            *bedrockImage            `json:"image,omitempty"`
+	ToolUse          *bedrockToolUse          `json:"toolUse,omitempty"`
+	ToolResult       *bedrockToolResult       `json:"toolResult,omitempty"`
+	ReasoningContent *bedrockReasoningContent `json:"reasoningContent,omitempty"`
+}
+
+type bedrockImage struct {
+	Format string `json:"format"` // "png", "jpeg", "gif" or "webp"
+	Source struct {
+		Bytes string `json:"bytes"`
+	} `json:"source"`
+}
+
+type bedrockToolUse struct {
+	ToolUseID string          `json:"toolUseId"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+}
+
+type bedrockToolResult struct {
+	ToolUseID string             `json:"toolUseId"`
+	Content   []bedrockTextBlock `json:"content"`
+}
+
+type bedrockTextBlock struct {
+	Text string `json:"text"`
+}
+
+type bedrockReasoningContent struct {
+	ReasoningText *struct {
+		Text string `json:"text"`
+	} `json:"reasoningText,omitempty"`
+}
+
+type bedrockInferenceConfig struct {
+	MaxTokens   int      `json:"maxTokens,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	TopP        *float64 `json:"topP,omitempty"`
+}
+
+type bedrockToolConfig struct {
+	Tools      []bedrockTool      `json:"tools"`
+	ToolChoice *bedrockToolChoice `json:"toolChoice,omitempty"`
+}
+
+type bedrockTool struct {
+	ToolSpec bedrockToolSpec `json:"toolSpec"`
+}
+
+type bedrockToolSpec struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema struct {
+		JSON json.RawMessage `json:"json"`
+	} `json:"inputSchema"`
+}
+
+// bedrockToolChoice sets exactly one of its fields.
+type bedrockToolChoice struct {
+	Auto *struct{} `json:"auto,omitempty"`
+	Any  *struct{} `json:"any,omitempty"`
+	Tool *struct {
+		Name string `json:"name"`
+	} `json:"tool,omitempty"`
+}
+
+type bedrockRequest struct {
+	Messages        []bedrockMessage        `json:"messages"`
+	System          []bedrockTextBlock      `json:"system,omitempty"`
+	InferenceConfig *bedrockInferenceConfig `json:"inferenceConfig,omitempty"`
+	ToolConfig      *bedrockToolConfig      `json:"toolConfig,omitempty"`
+}
+
+type bedrockUsage struct {
+	InputTokens          int `json:"inputTokens"`
+	OutputTokens         int `json:"outputTokens"`
+	TotalTokens          int `json:"totalTokens"`
+	CacheReadInputTokens int `json:"cacheReadInputTokens"`
+}
+
+type bedrockResponse struct {
+	Output struct {
+		Message *bedrockMessage `json:"message"`
+	} `json:"output"`
+	StopReason string        `json:"stopReason"`
+	Usage      *bedrockUsage `json:"usage"`
+	Message    string        `json:"message"` // error message
+}
+
+// bedrockStreamEvent is the payload of a ConverseStream event; which fields
+// are set depends on the event type.
+type bedrockStreamEvent struct {
+	ContentBlockIndex int `json:"contentBlockIndex"`
+	Start             *struct {
+		ToolUse *bedrockToolUse `json:"toolUse"`
+	} `json:"start"`
+	Delta *struct {
+		Text    string `json:"text"`
+		ToolUse *struct {
+			Input string `json:"input"`
+		} `json:"toolUse"`
+		ReasoningContent *struct {
+			Text string `json:"text"`
+		} `json:"reasoningContent"`
+	} `json:"delta"`
+	StopReason string        `json:"stopReason"`
+	Usage      *bedrockUsage `json:"usage"`
+	Message    string        `json:"message"` // exception message
+}
+
+// NewBedrockChat creates a chat model on the Bedrock Converse API.
+func NewBedrockChat(config *ChatConfig) (*BedrockChat, error) {
+	if strings.TrimSpace(config.APIKey) == "" {
+		return nil, fmt.Errorf("Bedrock provider: API key or secret access key is required")
+	}
+	region := provider.BedrockRegion(config.BaseURL, config.ExtraConfig)
+	baseURL := provider.BedrockRuntimeURL(config.BaseURL, region)
+	// Only a custom endpoint, such as a VPC endpoint, needs the SSRF check.
+	if baseURL != provider.BedrockRuntimeURL("", region) {
+		if err := secutils.ValidateURLForSSRF(baseURL); err != nil {
+			return nil, fmt.Errorf("baseURL SSRF check failed: %w", err)
+		}
+	}
+
+	return &BedrockChat{
+		modelName:     config.ModelName,
+		modelID:       config.ModelID,
+		baseURL:       baseURL,
+		auth:          provider.BedrockAuth(config.APIKey, region, config.ExtraConfig),
+		customHeaders: config.CustomHeaders,
+	}, nil
+}
+
+func (c *BedrockChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	ctx, cancel := withLLMTimeout(ctx, defaultChatTimeout)
+	defer cancel()
+
+	resp, err := c.send(ctx, messages, opts, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	var chatResp bedrockResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	state := newBedrockStreamState()
+	if msg := chatResp.Output.Message; msg != nil {
+		for i, block := range msg.Content {
+			state.applyBlock(i, block)
+		}
+	}
+	state.stopReason = chatResp.StopReason
+	state.usage = bedrockTokenUsage(chatResp.Usage)
+
+	result := &types.ChatResponse{
+		Content:          state.content.String(),
+		ReasoningContent: state.reasoning.String(),
+		ToolCalls:        state.orderedToolCalls(),
+		FinishReason:     state.finishReason(),
+	}
+	if state.usage != nil {
+		result.Usage = *state.usage
+	}
+	logUsage(ctx, c.modelName, &result.Usage)
+	return result, nil
+}
+
+func (c *BedrockChat) ChatStream(ctx context.Context, messages []Message, opts *ChatOptions) (<-chan types.StreamResponse, error) {
+	timeoutCtx, cancel := withLLMTimeout(ctx, defaultStreamTimeout)
+
+	resp, err := c.send(timeoutCtx, messages, opts, true)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	streamChan := make(chan types.StreamResponse)
+	go func() {
+		defer cancel()
+		processBedrockStream(timeoutCtx, c.modelName, resp, streamChan)
+	}()
+	return streamChan, nil
+}
+
+func (c *BedrockChat) GetModelName() string {
+	return c.modelName
+}
+
+func (c *BedrockChat) GetModelID() string {
+	return c.modelID
+}
+
+// send posts a Converse request and returns the response once it has a
+// success status.
+func (c *BedrockChat) send(ctx context.Context, messages []Message, opts *ChatOptions, isStream bool) (*http.Response, error) {
+	jsonData, err := json.Marshal(c.buildRequest(messages, opts))
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	endpoint := c.endpoint(isStream)
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if isStream {
+		httpReq.Header.Set("Accept", "application/vnd.amazon.eventstream")
+	}
+	secutils.ApplyCustomHeaders(httpReq, c.customHeaders)
+	if err := c.auth.Apply(ctx, httpReq, jsonData); err != nil {
+		return nil, err
+	}
+
+	resp, err := rawHTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		var errResp bedrockResponse
+		if json.Unmarshal(body, &errResp) == nil && errResp.Message != "" {
+			return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, errResp.Message)
+		}
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return resp, nil
+}
+
+func (c *BedrockChat) endpoint(isStream bool) string {
+	endpoint := fmt.Sprintf("%s/model/%s", c.baseURL, url.PathEscape(c.modelName))
+	if isStream {
+		return endpoint + "/converse-stream"
+	}
+	return endpoint + "/converse"
+}
+
+func (c *BedrockChat) buildRequest(messages []Message, opts *ChatOptions) bedrockRequest {
+	req := bedrockRequest{Messages: make([]bedrockMessage, 0, len(messages))}
+	if opts != nil {
+		req.InferenceConfig = bedrockInferenceConfigFrom(opts)
+		req.ToolConfig = bedrockToolConfigFrom(opts)
+	}
+
+	for _, msg := range messages {
+		content := strings.TrimSpace(msg.Content)
+		if content == "" {
+			content = textFromMultiContent(msg.MultiContent)
+		}
+		switch msg.Role {
+		case "system":
+			if content != "" {
+				req.System = append(req.System, bedrockTextBlock{Text: content})
+			}
+		case "assistant":
+			var blocks []bedrockContentBlock
+			if content != "" {
+				blocks = append(blocks, bedrockContentBlock{Text: content})
+			}
+			for _, tc := range msg.ToolCalls {
+				input := json.RawMessage(tc.Function.Arguments)
+				if !json.Valid(input) {
+					input = json.RawMessage("{}")
+				}
+				blocks = append(blocks, bedrockContentBlock{ToolUse: &bedrockToolUse{
+					ToolUseID: tc.ID,
+					Name:      tc.Function.Name,
+					Input:     input,
+				}})
+			}
+			if len(blocks) > 0 {
+				req.Messages = appendBedrockMessage(req.Messages, "assistant", blocks)
+			}
+		case "tool":
+			if content == "" {
+				content = "(empty)"
+			}
+			req.Messages = appendBedrockMessage(req.Messages, "user", []bedrockContentBlock{{
+				ToolResult: &bedrockToolResult{
+					ToolUseID: msg.ToolCallID,
+					Content:   []bedrockTextBlock{{Text: content}},
+				},
+			}})
+		default:
+			blocks := bedrockImageBlocks(msg)
+			if content != "" {
+				blocks = append(blocks, bedrockContentBlock{Text: content})
+			}
+			if len(blocks) > 0 {
+				req.Messages = appendBedrockMessage(req.Messages, "user", blocks)
+			}
+		}
+	}
+	return req
+}
+
+// appendBedrockMessage appends a turn, merging it into the previous one when
+// the roles match: Converse requires user and assistant turns to alternate,
+// and the results of one turn's tool calls go back together.
+func appendBedrockMessage(messages []bedrockMessage, role string, blocks []bedrockContentBlock) []bedrockMessage {
+	if n := len(messages); n > 0 && messages[n-1].Role == role {
+		messages[n-1].Content = append(messages[n-1].Content, blocks...)
+		return messages
+	}
+	return append(messages, bedrockMessage{Role: role, Content: blocks})
+}
+
+func bedrockInferenceConfigFrom(opts *ChatOptions) *bedrockInferenceConfig {
+	cfg := &bedrockInferenceConfig{}
+	if opts.Temperature > 0 {
+		temperature := opts.Temperature
+		cfg.Temperature = &temperature
+	}
+	if opts.TopP > 0 {
+		topP := opts.TopP
+		cfg.TopP = &topP
+	}
+	if opts.MaxTokens > 0 {
+		cfg.MaxTokens = opts.MaxTokens
+	} else if opts.MaxCompletionTokens > 0 {
+		cfg.MaxTokens = opts.MaxCompletionTokens
+	}
+	if cfg.Temperature == nil && cfg.TopP == nil && cfg.MaxTokens == 0 {
+		return nil
+	}
+	return cfg
+}
+
+// bedrockToolConfigFrom maps the tools of opts, or its JSON schema response
+// format, to the tool config of a Converse request. Converse has no "none"
+// tool choice, so that leaves the choice to the model.
+func bedrockToolConfigFrom(opts *ChatOptions) *bedrockToolConfig {
+	if len(opts.Tools) == 0 {
+		if len(opts.Format) == 0 {
+			return nil
+		}
+		cfg := &bedrockToolConfig{Tools: []bedrockTool{
+			bedrockToolOf(bedrockStructuredOutputTool, "Respond with a JSON object matching this schema.", opts.Format),
+		}}
+		cfg.ToolChoice = bedrockNamedToolChoice(bedrockStructuredOutputTool)
+		return cfg
+	}
+
+	cfg := &bedrockToolConfig{Tools: make([]bedrockTool, 0, len(opts.Tools))}
+	for _, tool := range opts.Tools {
+		cfg.Tools = append(cfg.Tools, bedrockToolOf(tool.Function.Name, tool.Function.Description, tool.Function.Parameters))
+	}
+	switch opts.ToolChoice {
+	case "", "auto", "none":
+	case "required":
+		cfg.ToolChoice = &bedrockToolChoice{Any: &struct{}{}}
+	default:
+		cfg.ToolChoice = bedrockNamedToolChoice(opts.ToolChoice)
+	}
+	return cfg
+}
+
+func bedrockToolOf(name, description string, schema json.RawMessage) bedrockTool {
+	if len(schema) == 0 {
+		schema = json.RawMessage(`{"type":"object","properties":{}}`)
+	}
+	spec := bedrockToolSpec{Name: name, Description: description}
+	spec.InputSchema.JSON = schema
+	return bedrockTool{ToolSpec: spec}
+}
+
+func bedrockNamedToolChoice(name string) *bedrockToolChoice {
+	return &bedrockToolChoice{Tool: &struct {
+		Name string `json:"name"`
+	}{Name: name}}
+}
+
+// bedrockImageBlocks returns the image blocks of a user message, taken from
+// its multi-content parts or its attached images. Converse only accepts
+// inline image bytes, so images that cannot be resolved to data are dropped.
+func bedrockImageBlocks(msg Message) []bedrockContentBlock {
+	var urls []string
+	for _, part := range msg.MultiContent {
+		if part.Type == "image_url" && part.ImageURL != nil {
+			urls = append(urls, part.ImageURL.URL)
+		}
+	}
+	if len(msg.MultiContent) == 0 {
+		urls = msg.Images
+	}
+	blocks := make([]bedrockContentBlock, 0, len(urls))
+	for _, u := range urls {
+		mimeType, data, ok := splitDataURI(resolveImageURLForLLM(u))
+		if !ok {
+			continue
+		}
+		format := strings.TrimPrefix(mimeType, "image/")
+		if format == "jpg" {
+			format = "jpeg"
+		}
+		image := &bedrockImage{Format: format}
+		image.Source.Bytes = data
+		blocks = append(blocks, bedrockContentBlock{Image: image})
+	}
+	return blocks
+}
+
+func bedrockTokenUsage(usage *bedrockUsage) *types.TokenUsage {
+	if usage == nil {
+		return nil
+	}
+	total := usage.TotalTokens
+	if total == 0 {
+		total = usage.InputTokens + usage.OutputTokens
+	}
+	return &types.TokenUsage{
+		PromptTokens:     usage.InputTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      total,
+		CachedTokens:     usage.CacheReadInputTokens,
+	}
+}
+
+// bedrockStreamState accumulates the answer of a Converse response or of the
+// events of a streamed one.
+type bedrockStreamState struct {
+	content    strings.Builder
+	reasoning  strings.Builder
+	toolCalls  map[int]*types.LLMToolCall
+	toolOrder  []int
+	structured map[int]bool
+	usage      *types.TokenUsage
+	stopReason string
+}
+
+// bedrockDelta is a piece of a streamed answer.
+type bedrockDelta struct {
+	thought  bool
+	text     string
+	toolCall *types.LLMToolCall
+}
+
+func newBedrockStreamState() *bedrockStreamState {
+	return &bedrockStreamState{
+		toolCalls:  make(map[int]*types.LLMToolCall),
+		structured: make(map[int]bool),
+	}
+}
+
+// applyBlock folds a complete content block of a Converse response into the
+// state.
+func (s *bedrockStreamState) applyBlock(index int, block bedrockContentBlock) {
+	switch {
+	case block.ToolUse != nil:
+		if block.ToolUse.Name == bedrockStructuredOutputTool {
+			s.content.Write(block.ToolUse.Input)
+			return
+		}
+		s.startToolCall(index, block.ToolUse)
+		s.toolCalls[index].Function.Arguments = string(block.ToolUse.Input)
+	case block.ReasoningContent != nil && block.ReasoningContent.ReasoningText != nil:
+		s.reasoning.WriteString(block.ReasoningContent.ReasoningText.Text)
+	default:
+		s.content.WriteString(block.Text)
+	}
+}
+
+// apply folds a ConverseStream event into the state and returns the piece of
+// the answer it carries, if any.
+func (s *bedrockStreamState) apply(eventType string, event *bedrockStreamEvent) bedrockDelta {
+	switch eventType {
+	case "contentBlockStart":
+		if event.Start == nil || event.Start.ToolUse == nil {
+			return bedrockDelta{}
+		}
+		if event.Start.ToolUse.Name == bedrockStructuredOutputTool {
+			s.structured[event.ContentBlockIndex] = true
+			return bedrockDelta{}
+		}
+		return bedrockDelta{toolCall: s.startToolCall(event.ContentBlockIndex, event.Start.ToolUse)}
+	case "contentBlockDelta":
+		if event.Delta == nil {
+			return bedrockDelta{}
+		}
+		switch {
+		case event.Delta.ToolUse != nil:
+			if s.structured[event.ContentBlockIndex] {
+				s.content.WriteString(event.Delta.ToolUse.Input)
+				return bedrockDelta{text: event.Delta.ToolUse.Input}
+			}
+			if call, ok := s.toolCalls[event.ContentBlockIndex]; ok {
+				call.Function.Arguments += event.Delta.ToolUse.Input
+			}
+		case event.Delta.ReasoningContent != nil:
+			s.reasoning.WriteString(event.Delta.ReasoningContent.Text)
+			return bedrockDelta{thought: true, text: event.Delta.ReasoningContent.Text}
+		default:
+			s.content.WriteString(event.Delta.Text)
+			return bedrockDelta{text: event.Delta.Text}
+		}
+	case "messageStop":
+		s.stopReason = event.StopReason
+	case "metadata":
+		s.usage = bedrockTokenUsage(event.Usage)
+	}
+	return bedrockDelta{}
+}
+
+func (s *bedrockStreamState) startToolCall(index int, toolUse *bedrockToolUse) *types.LLMToolCall {
+	call := &types.LLMToolCall{
+		ID:       toolUse.ToolUseID,
+		Type:     "function",
+		Function: types.FunctionCall{Name: toolUse.Name},
+	}
+	s.toolCalls[index] = call
+	s.toolOrder = append(s.toolOrder, index)
+	return call
+}
+
+// orderedToolCalls returns the tool calls in order, with the empty arguments
+// of a tool without parameters sent as an empty object.
+func (s *bedrockStreamState) orderedToolCalls() []types.LLMToolCall {
+	if len(s.toolOrder) == 0 {
+		return nil
+	}
+	calls := make([]types.LLMToolCall, 0, len(s.toolOrder))
+	for _, idx := range s.toolOrder {
+		call := *s.toolCalls[idx]
+		if call.Function.Arguments == "" {
+			call.Function.Arguments = "{}"
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// finishReason maps the Converse stop reason to the OpenAI finish reason
+// callers branch on. A tool_use stop without tool calls is the end of a
+// structured output answer.
+func (s *bedrockStreamState) finishReason() string {
+	switch s.stopReason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "max_tokens", "model_context_window_exceeded":
+		return "length"
+	case "tool_use":
+		if len(s.toolOrder) > 0 {
+			return "tool_calls"
+		}
+		return "stop"
+	case "guardrail_intervened", "content_filtered":
+		return "content_filter"
+	default:
+		return s.stopReason
+	}
+}
+
+func processBedrockStream(ctx context.Context, model string, resp *http.Response, streamChan chan types.StreamResponse) {
+	defer close(streamChan)
+	defer resp.Body.Close()
+
+	decoder := eventstream.NewDecoder()
+	state := newBedrockStreamState()
+	var thinking thinkingEmitter
+	sendError := func(content string) {
+		streamChan <- types.StreamResponse{
+			ResponseType: types.ResponseTypeError,
+			Content:      content,
+			Done:         true,
+		}
+	}
+
+	for {
+		msg, err := decoder.Decode(resp.Body, nil)
+		if errors.Is(err, io.EOF) {
+			thinking.finish(streamChan)
+			logUsage(ctx, model, state.usage)
+			streamChan <- types.StreamResponse{
+				ResponseType: types.ResponseTypeAnswer,
+				Content:      "",
+				Done:         true,
+				ToolCalls:    state.orderedToolCalls(),
+				Usage:        state.usage,
+				FinishReason: state.finishReason(),
+			}
+			return
+		}
+		if err != nil {
+			sendError(fmt.Sprintf("decode event stream: %v", err))
+			return
+		}
+
+		var event bedrockStreamEvent
+		if len(msg.Payload) > 0 {
+			if err := json.Unmarshal(msg.Payload, &event); err != nil {
+				sendError(fmt.Sprintf("decode event stream: %v", err))
+				return
+			}
+		}
+		switch bedrockHeader(msg, ":message-type") {
+		case "exception":
+			sendError(fmt.Sprintf("%s: %s", bedrockHeader(msg, ":exception-type"), event.Message))
+			return
+		case "error":
+			sendError(fmt.Sprintf("%s: %s", bedrockHeader(msg, ":error-code"), bedrockHeader(msg, ":error-message")))
+			return
+		}
+
+		delta := state.apply(bedrockHeader(msg, ":event-type"), &event)
+		switch {
+		case delta.toolCall != nil:
+			streamChan <- types.StreamResponse{
+				ResponseType: types.ResponseTypeToolCall,
+				Content:      "",
+				Done:         false,
+				Data: map[string]interface{}{
+					"tool_name":    delta.toolCall.Function.Name,
+					"tool_call_id": delta.toolCall.ID,
+				},
+			}
+		case delta.text == "":
+		case delta.thought:
+			thinking.emit(streamChan, delta.text)
+		default:
+			thinking.finish(streamChan)
+			streamChan <- types.StreamResponse{
+				ResponseType: types.ResponseTypeAnswer,
+				Content:      delta.text,
+				Done:         false,
+			}
+		}
+	}
+}
+
+func bedrockHeader(msg eventstream.Message, name string) string {
+	if value := msg.Headers.Get(name); value != nil {
+		return value.String()
+	}
+	return ""
+}
@@ -0,0 +1,200 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/aws/aws-sdk-go-v2/aws/protocol/eventstream"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBedrockChat(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+
+	var capturedHeaders http.Header
+	var capturedRequest bedrockRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/model/anthropic.claude-sonnet-4:0/converse", r.URL.Path)
+		capturedHeaders = r.Header.Clone()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&capturedRequest))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"output":{"message":{"role":"assistant","content":[
+				{"text":"Let me check."},
+				{"toolUse":{"toolUseId":"tooluse_2","name":"search","input":{"q":"leave"}}}
+			]}},
+			"stopReason":"tool_use",
+			"usage":{"inputTokens":12,"outputTokens":5,"totalTokens":17}
+		}`))
+	}))
+	defer server.Close()
+
+	chat, err := NewBedrockChat(&ChatConfig{
+		BaseURL:     server.URL,
+		ModelName:   "anthropic.claude-sonnet-4:0",
+		APIKey:      "secret",
+		ExtraConfig: map[string]string{"region": "us-west-2", "access_key_id": "AKIDEXAMPLE"},
+	})
+	require.NoError(t, err)
+
+	resp, err := chat.Chat(context.Background(), []Message{
+		{Role: "system", Content: "You are helpful."},
+		{Role: "user", Content: "How much leave?", Images: []string{"data:image/png;base64,AAAA"}},
+		{Role: "assistant", ToolCalls: []ToolCall{
+			{ID: "tooluse_1", Type: "function", Function: FunctionCall{Name: "search", Arguments: `{"q":"annual"}`}},
+			{ID: "tooluse_0", Type: "function", Function: FunctionCall{Name: "search", Arguments: `{"q":"sick"}`}},
+		}},
+		{Role: "tool", ToolCallID: "tooluse_1", Content: "20 days"},
+		{Role: "tool", ToolCallID: "tooluse_0", Content: "10 days"},
+	}, &ChatOptions{
+		Temperature: 0.2,
+		MaxTokens:   100,
+		Tools:       []Tool{{Type: "function", Function: FunctionDef{Name: "search", Parameters: json.RawMessage(`{"type":"object"}`)}}},
+		ToolChoice:  "required",
+	})
+	require.NoError(t, err)
+
+	auth := capturedHeaders.Get("Authorization")
+	assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/"), auth)
+	assert.Contains(t, auth, "/us-west-2/bedrock/aws4_request")
+	assert.NotEmpty(t, capturedHeaders.Get("X-Amz-Date"))
+
+	require.Len(t, capturedRequest.System, 1)
+	assert.Equal(t, "You are helpful.", capturedRequest.System[0].Text)
+	require.Len(t, capturedRequest.Messages, 3)
+	user := capturedRequest.Messages[0]
+	assert.Equal(t, "user", user.Role)
+	require.NotNil(t, user.Content[0].Image)
+	assert.Equal(t, "png", user.Content[0].Image.Format)
+	assert.Equal(t, "AAAA", user.Content[0].Image.Source.Bytes)
+	assert.Equal(t, "How much leave?", user.Content[1].Text)
+	assistant := capturedRequest.Messages[1]
+	require.Len(t, assistant.Content, 2)
+	assert.Equal(t, "tooluse_1", assistant.Content[0].ToolUse.ToolUseID)
+	results := capturedRequest.Messages[2]
+	assert.Equal(t, "user", results.Role)
+	require.Len(t, results.Content, 2, "the results of one turn go back together")
+	assert.Equal(t, "tooluse_0", results.Content[1].ToolResult.ToolUseID)
+	assert.Equal(t, "10 days", results.Content[1].ToolResult.Content[0].Text)
+	require.NotNil(t, capturedRequest.ToolConfig.ToolChoice)
+	assert.NotNil(t, capturedRequest.ToolConfig.ToolChoice.Any)
+	assert.Equal(t, 100, capturedRequest.InferenceConfig.MaxTokens)
+
+	assert.Equal(t, "Let me check.", resp.Content)
+	require.Len(t, resp.ToolCalls, 1)
+	assert.Equal(t, "tooluse_2", resp.ToolCalls[0].ID)
+	assert.JSONEq(t, `{"q":"leave"}`, resp.ToolCalls[0].Function.Arguments)
+	assert.Equal(t, "tool_calls", resp.FinishReason)
+	assert.Equal(t, 17, resp.Usage.TotalTokens)
+}
+
+func TestBedrockChat_APIKeyAuth(t *testing.T) {
+	chat, err := NewBedrockChat(&ChatConfig{ModelName: "amazon.nova-pro-v1:0", APIKey: "bedrock-api-key"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "https://bedrock-runtime.us-east-1.amazonaws.com", chat.baseURL)
+	assert.Equal(t, "bedrock-api-key", chat.auth.APIKey)
+	assert.Empty(t, chat.auth.AccessKeyID)
+}
+
+func TestBedrockChat_StructuredOutput(t *testing.T) {
+	chat := &BedrockChat{modelName: "anthropic.claude-sonnet-4:0"}
+	schema := json.RawMessage(`{"type":"object","properties":{"answer":{"type":"string"}}}`)
+	req := chat.buildRequest([]Message{{Role: "user", Content: "Is it?"}}, &ChatOptions{Format: schema})
+
+	require.NotNil(t, req.ToolConfig)
+	require.Len(t, req.ToolConfig.Tools, 1)
+	assert.Equal(t, bedrockStructuredOutputTool, req.ToolConfig.Tools[0].ToolSpec.Name)
+	assert.JSONEq(t, string(schema), string(req.ToolConfig.Tools[0].ToolSpec.InputSchema.JSON))
+	assert.Equal(t, bedrockStructuredOutputTool, req.ToolConfig.ToolChoice.Tool.Name)
+
+	state := newBedrockStreamState()
+	state.applyBlock(0, bedrockContentBlock{ToolUse: &bedrockToolUse{
+		ToolUseID: "tooluse_1", Name: bedrockStructuredOutputTool, Input: json.RawMessage(`{"answer":"yes"}`),
+	}})
+	state.stopReason = "tool_use"
+	assert.JSONEq(t, `{"answer":"yes"}`, state.content.String())
+	assert.Empty(t, state.orderedToolCalls())
+	assert.Equal(t, "stop", state.finishReason())
+}
+
+func TestBedrockChat_ChatStream(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+
+	events := []struct {
+		eventType string
+		payload   string
+	}{
+		{"messageStart", `{"role":"assistant"}`},
+		{"contentBlockDelta", `{"contentBlockIndex":0,"delta":{"reasoningContent":{"text":"Thinking it over"}}}`},
+		{"contentBlockDelta", `{"contentBlockIndex":1,"delta":{"text":"po"}}`},
+		{"contentBlockDelta", `{"contentBlockIndex":1,"delta":{"text":"ng"}}`},
+		{"contentBlockStart", `{"contentBlockIndex":2,"start":{"toolUse":{"toolUseId":"tooluse_1","name":"search"}}}`},
+		{"contentBlockDelta", `{"contentBlockIndex":2,"delta":{"toolUse":{"input":"{\"q\":"}}}`},
+		{"contentBlockDelta", `{"contentBlockIndex":2,"delta":{"toolUse":{"input":"\"ping\"}"}}}`},
+		{"messageStop", `{"stopReason":"tool_use"}`},
+		{"metadata", `{"usage":{"inputTokens":7,"outputTokens":2,"totalTokens":9}}`},
+	}
+	var body bytes.Buffer
+	encoder := eventstream.NewEncoder()
+	for _, event := range events {
+		var headers eventstream.Headers
+		headers.Set(":message-type", eventstream.StringValue("event"))
+		headers.Set(":event-type", eventstream.StringValue(event.eventType))
+		require.NoError(t, encoder.Encode(&body, eventstream.Message{Headers: headers, Payload: []byte(event.payload)}))
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/model/amazon.nova-pro-v1:0/converse-stream", r.URL.Path)
+		assert.Equal(t, "Bearer bedrock-api-key", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		_, _ = w.Write(body.Bytes())
+	}))
+	defer server.Close()
+
+	chat, err := NewBedrockChat(&ChatConfig{BaseURL: server.URL, ModelName: "amazon.nova-pro-v1:0", APIKey: "bedrock-api-key"})
+	require.NoError(t, err)
+
+	ch, err := chat.ChatStream(context.Background(), []Message{{Role: "user", Content: "ping"}}, &ChatOptions{})
+	require.NoError(t, err)
+
+	var chunks []types.StreamResponse
+	for chunk := range ch {
+		chunks = append(chunks, chunk)
+	}
+
+	require.Len(t, chunks, 6)
+	assert.Equal(t, types.ResponseTypeThinking, chunks[0].ResponseType)
+	assert.Equal(t, "Thinking it over", chunks[0].Content)
+	assert.True(t, chunks[1].Done)
+	assert.Equal(t, "po", chunks[2].Content)
+	assert.Equal(t, "ng", chunks[3].Content)
+	assert.Equal(t, types.ResponseTypeToolCall, chunks[4].ResponseType)
+	last := chunks[5]
+	assert.True(t, last.Done)
+	assert.Equal(t, "tool_calls", last.FinishReason)
+	require.Len(t, last.ToolCalls, 1)
+	assert.JSONEq(t, `{"q":"ping"}`, last.ToolCalls[0].Function.Arguments)
+	require.NotNil(t, last.Usage)
+	assert.Equal(t, 9, last.Usage.TotalTokens)
+}
+
+func TestNewRemoteChat_BedrockProvider(t *testing.T) {
+	c, err := NewRemoteChat(&ChatConfig{
+		Source:    types.ModelSourceRemote,
+		ModelName: "amazon.nova-pro-v1:0",
+		APIKey:    "bedrock-api-key",
+		Provider:  string(provider.ProviderBedrock),
+	})
+	require.NoError(t, err)
+	assert.IsType(t, &BedrockChat{}, c)
+}
@@ -146,13 +146,20 @@ func NewChat(config *ChatConfig, ollamaService *ollama.OllamaService) (Chat, err
 	default:
 		return nil, fmt.Errorf("unsupported chat model source: %s", config.Source)
 	}
+	c, err = wrapChatUsage(c, err)
+	c, err = wrapChatMetrics(c, err)
+	c, err = wrapChatTracing(c, err)
 	c, err = wrapChatDebug(c, err)
-	return wrapChatLangfuse(c, err)
+	c, err = wrapChatLangfuse(c, err)
+	return wrapChatCache(c, err)
 }
 
 // NewRemoteChat 根据 provider 创建远程聊天实例。
-// Anthropic 走独立的 Messages 协议实现；其余 OpenAI 兼容供应商统一由
-// RemoteAPIChat 处理，provider 特定行为在构造时通过 providerAdapter 解析。
+// Anthropic 走独立的 Messages 协议实现；Gemini 走原生 generateContent 协议实现
+// （BaseURL 指向 OpenAI 兼容端点 .../openai 时仍按 OpenAI 兼容方式调用）；
+// Amazon Bedrock 走 Converse 协议实现；自托管推理服务由 SelfHostedChat 在多个副本间路由；
+// 其余 OpenAI 兼容供应商统一由 RemoteAPIChat 处理，provider 特定行为在构造时
+// 通过 providerAdapter 解析。
 func NewRemoteChat(config *ChatConfig) (Chat, error) {
 	providerName := provider.ProviderName(config.Provider)
 	if providerName == "" {
@@ -161,5 +168,35 @@ func NewRemoteChat(config *ChatConfig) (Chat, error) {
 	if providerName == provider.ProviderAnthropic {
 		return NewAnthropicChat(config)
 	}
+	if providerName == provider.ProviderGemini && !isGeminiOpenAICompatURL(config.BaseURL) {
+		return NewGeminiChat(config)
+	}
+	if providerName == provider.ProviderBedrock {
+		return NewBedrockChat(config)
+	}
+	if providerName == provider.ProviderSelfHosted {
+		return NewSelfHostedChat(config)
+	}
 	return NewRemoteAPIChat(config)
 }
+
+// contextLengthReporter 由能探测模型上下文长度的实现提供（如 SelfHostedChat）
+type contextLengthReporter interface {
+	ContextLength(ctx context.Context) int
+}
+
+// ContextLength 返回 c 探测到的模型上下文长度，会穿过 debug / langfuse / 缓存等包装层查找；
+// 无法获知时返回 0。
+func ContextLength(ctx context.Context, c Chat) int {
+	for c != nil {
+		if r, ok := c.(contextLengthReporter); ok {
+			return r.ContextLength(ctx)
+		}
+		w, ok := c.(interface{ Unwrap() Chat })
+		if !ok {
+			return 0
+		}
+		c = w.Unwrap()
+	}
+	return 0
+}
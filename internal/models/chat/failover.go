@@ -0,0 +1,101 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/sashabaranov/go-openai"
+)
+
+// statusInErrorPattern finds the HTTP status in the errors of the raw HTTP
+// and Anthropic clients ("API request failed with status 503: ...").
+var statusInErrorPattern = regexp.MustCompile(`status (\d{3})`)
+
+// IsFailoverError reports whether a chat call failed because the model is
+// unavailable for now, so that the turn may be retried on another model:
+// it was rate limited, timed out, or failed with a server error. Errors of
+// the request itself, and a cancelled or expired ctx of the caller, are not
+// failover errors.
+func IsFailoverError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	if status := errorHTTPStatus(err); status != 0 {
+		return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "rate limit") || strings.Contains(msg, "timeout")
+}
+
+// errorHTTPStatus returns the HTTP status a chat call failed with, or 0.
+func errorHTTPStatus(err error) int {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) && apiErr.HTTPStatusCode != 0 {
+		return apiErr.HTTPStatusCode
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode != 0 {
+		return reqErr.HTTPStatusCode
+	}
+	if m := statusInErrorPattern.FindStringSubmatch(err.Error()); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		return status
+	}
+	return 0
+}
+
+// AdaptMessages reshapes messages built for the chat model from to be sent
+// to the chat model to: images are dropped for a model without vision, and
+// the reasoning content of earlier answers, which only the provider that
+// produced it reads back, is dropped when the provider changes. The
+// messages are not modified.
+func AdaptMessages(messages []Message, from, to *types.Model) []Message {
+	adapted := make([]Message, len(messages))
+	copy(adapted, messages)
+	if !to.Parameters.SupportsVision {
+		for i, msg := range adapted {
+			adapted[i].Images = nil
+			if len(msg.MultiContent) > 0 {
+				adapted[i].MultiContent = textParts(msg.MultiContent)
+			}
+		}
+	}
+	if from == nil || modelProvider(from) != modelProvider(to) {
+		for i := range adapted {
+			adapted[i].ReasoningContent = ""
+		}
+	}
+	return adapted
+}
+
+// textParts returns the text parts of a multi-content message.
+func textParts(parts []MessageContentPart) []MessageContentPart {
+	var text []MessageContentPart
+	for _, part := range parts {
+		if part.Type != "image_url" {
+			text = append(text, part)
+		}
+	}
+	return text
+}
+
+// modelProvider identifies the provider serving a model.
+func modelProvider(model *types.Model) string {
+	if model.Parameters.Provider != "" {
+		return model.Parameters.Provider
+	}
+	return string(model.Source)
+}
@@ -0,0 +1,55 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/sashabaranov/go-openai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsFailoverError(t *testing.T) {
+	ctx := context.Background()
+	for name, tc := range map[string]struct {
+		err  error
+		want bool
+	}{
+		"rate limited":     {&openai.APIError{HTTPStatusCode: 429}, true},
+		"server error":     {fmt.Errorf("create chat completion stream: %w", &openai.RequestError{HTTPStatusCode: 502}), true},
+		"raw http status":  {errors.New("API request failed with status 503: overloaded"), true},
+		"deadline":         {fmt.Errorf("call: %w", context.DeadlineExceeded), true},
+		"bad request":      {&openai.APIError{HTTPStatusCode: 400, Message: "timeout must be positive"}, false},
+		"unauthorized":     {errors.New("API request failed with status 401: invalid key"), false},
+		"other error":      {errors.New("invalid tool schema"), false},
+		"rate limit error": {errors.New("Rate limit reached for requests"), true},
+	} {
+		assert.Equal(t, tc.want, IsFailoverError(ctx, tc.err), name)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.False(t, IsFailoverError(cancelled, &openai.APIError{HTTPStatusCode: 503}))
+}
+
+func TestAdaptMessages(t *testing.T) {
+	messages := []Message{
+		{Role: "assistant", Content: "earlier answer", ReasoningContent: "thinking"},
+		{Role: "user", Content: "what is this?", Images: []string{"data:image/png;base64,AAAA"}},
+	}
+	vision := &types.Model{Source: types.ModelSourceDeepseek, Parameters: types.ModelParameters{SupportsVision: true}}
+	sameProvider := &types.Model{Source: types.ModelSourceDeepseek, Parameters: types.ModelParameters{SupportsVision: true}}
+	textOnly := &types.Model{Source: types.ModelSourceOpenAI}
+
+	kept := AdaptMessages(messages, vision, sameProvider)
+	assert.Equal(t, messages, kept)
+
+	adapted := AdaptMessages(messages, vision, textOnly)
+	assert.Empty(t, adapted[0].ReasoningContent)
+	assert.Nil(t, adapted[1].Images)
+	assert.Equal(t, "what is this?", adapted[1].Content)
+	assert.Equal(t, "thinking", messages[0].ReasoningContent, "input is not modified")
+	assert.Len(t, messages[1].Images, 1)
+}
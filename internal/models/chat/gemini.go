@@ -0,0 +1,643 @@
+package chat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/types"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+)
+
+// geminiThoughtSignatureKey is the ToolCallMetadata key under which the
+// thought signature of a Gemini function call is carried, so that it can be
+// sent back with the call on the next turn as the API requires.
+const geminiThoughtSignatureKey = "thought_signature"
+
+// GeminiChat talks to Gemini through the native generateContent API.
+type GeminiChat struct {
+	modelName     string
+	modelID       string
+	baseURL       string
+	apiKey        string
+	customHeaders map[string]string
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	Thought          bool                    `json:"thought,omitempty"`
+	ThoughtSignature string                  `json:"thoughtSignature,omitempty"`
+	InlineData       *geminiBlob             `json:"inlineData,omitempty"`
+	FileData         *geminiFileData         `json:"fileData,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+type geminiBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+type geminiFileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+type geminiFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type geminiFunctionResponse struct {
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+type geminiFunctionDeclaration struct {
+	Name                 string          `json:"name"`
+	Description          string          `json:"description,omitempty"`
+	ParametersJSONSchema json.RawMessage `json:"parametersJsonSchema,omitempty"`
+}
+
+type geminiToolConfig struct {
+	FunctionCallingConfig geminiFunctionCallingConfig `json:"functionCallingConfig"`
+}
+
+type geminiFunctionCallingConfig struct {
+	Mode                 string   `json:"mode"` // "AUTO", "ANY" or "NONE"
+	AllowedFunctionNames []string `json:"allowedFunctionNames,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature        *float64              `json:"temperature,omitempty"`
+	TopP               *float64              `json:"topP,omitempty"`
+	MaxOutputTokens    int                   `json:"maxOutputTokens,omitempty"`
+	Seed               int                   `json:"seed,omitempty"`
+	PresencePenalty    float64               `json:"presencePenalty,omitempty"`
+	FrequencyPenalty   float64               `json:"frequencyPenalty,omitempty"`
+	ResponseMimeType   string                `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema json.RawMessage       `json:"responseJsonSchema,omitempty"`
+	ThinkingConfig     *geminiThinkingConfig `json:"thinkingConfig,omitempty"`
+}
+
+type geminiThinkingConfig struct {
+	IncludeThoughts bool `json:"includeThoughts"`
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent         `json:"contents"`
+	SystemInstruction *geminiContent          `json:"systemInstruction,omitempty"`
+	Tools             []geminiTool            `json:"tools,omitempty"`
+	ToolConfig        *geminiToolConfig       `json:"toolConfig,omitempty"`
+	GenerationConfig  *geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback *struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback,omitempty"`
+	UsageMetadata *struct {
+		PromptTokenCount        int `json:"promptTokenCount"`
+		CandidatesTokenCount    int `json:"candidatesTokenCount"`
+		ThoughtsTokenCount      int `json:"thoughtsTokenCount"`
+		TotalTokenCount         int `json:"totalTokenCount"`
+		CachedContentTokenCount int `json:"cachedContentTokenCount"`
+	} `json:"usageMetadata,omitempty"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error,omitempty"`
+}
+
+// NewGeminiChat creates a chat model on the native Gemini API.
+func NewGeminiChat(config *ChatConfig) (*GeminiChat, error) {
+	if config.BaseURL != "" {
+		if err := secutils.ValidateURLForSSRF(config.BaseURL); err != nil {
+			return nil, fmt.Errorf("baseURL SSRF check failed: %w", err)
+		}
+	}
+	if strings.TrimSpace(config.APIKey) == "" {
+		return nil, fmt.Errorf("Gemini provider: API key is required")
+	}
+
+	baseURL := strings.TrimRight(config.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = provider.GeminiBaseURL
+	}
+
+	return &GeminiChat{
+		modelName:     strings.TrimPrefix(config.ModelName, "models/"),
+		modelID:       config.ModelID,
+		baseURL:       baseURL,
+		apiKey:        config.APIKey,
+		customHeaders: config.CustomHeaders,
+	}, nil
+}
+
+// isGeminiOpenAICompatURL reports whether baseURL is Gemini's OpenAI
+// compatible endpoint, which is served by RemoteAPIChat rather than the
+// native client.
+func isGeminiOpenAICompatURL(baseURL string) bool {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	return strings.HasSuffix(strings.TrimRight(u.Path, "/"), "/openai")
+}
+
+func (c *GeminiChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	ctx, cancel := withLLMTimeout(ctx, defaultChatTimeout)
+	defer cancel()
+
+	resp, err := c.send(ctx, messages, opts, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	var chatResp geminiResponse
+	if err := json.Unmarshal(body, &chatResp); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	state := &geminiStreamState{}
+	state.apply(&chatResp)
+	result := &types.ChatResponse{
+		Content:          state.content.String(),
+		ReasoningContent: state.reasoning.String(),
+		ToolCalls:        state.toolCalls,
+		FinishReason:     state.finishReason(),
+	}
+	if state.usage != nil {
+		result.Usage = *state.usage
+	}
+	logUsage(ctx, c.modelName, &result.Usage)
+	return result, nil
+}
+
+func (c *GeminiChat) ChatStream(ctx context.Context, messages []Message, opts *ChatOptions) (<-chan types.StreamResponse, error) {
+	timeoutCtx, cancel := withLLMTimeout(ctx, defaultStreamTimeout)
+
+	resp, err := c.send(timeoutCtx, messages, opts, true)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	streamChan := make(chan types.StreamResponse)
+	go func() {
+		defer cancel()
+		processGeminiStream(timeoutCtx, c.modelName, resp, streamChan)
+	}()
+	return streamChan, nil
+}
+
+func (c *GeminiChat) GetModelName() string {
+	return c.modelName
+}
+
+func (c *GeminiChat) GetModelID() string {
+	return c.modelID
+}
+
+// send posts a generateContent request and returns the response once it has
+// a success status.
+func (c *GeminiChat) send(ctx context.Context, messages []Message, opts *ChatOptions, isStream bool) (*http.Response, error) {
+	jsonData, err := json.Marshal(c.buildRequest(messages, opts))
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	endpoint := c.endpoint(isStream)
+	if err := secutils.ValidateURLForSSRF(endpoint); err != nil {
+		return nil, fmt.Errorf("endpoint SSRF check failed: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if isStream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	httpReq.Header.Set("x-goog-api-key", c.apiKey)
+	secutils.ApplyCustomHeaders(httpReq, c.customHeaders)
+
+	resp, err := rawHTTPClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("send request: %w", err)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		var errResp geminiResponse
+		if json.Unmarshal(body, &errResp) == nil && errResp.Error != nil && errResp.Error.Message != "" {
+			return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, errResp.Error.Message)
+		}
+		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return resp, nil
+}
+
+func (c *GeminiChat) endpoint(isStream bool) string {
+	endpoint := fmt.Sprintf("%s/models/%s", c.baseURL, url.PathEscape(c.modelName))
+	if isStream {
+		return endpoint + ":streamGenerateContent?alt=sse"
+	}
+	return endpoint + ":generateContent"
+}
+
+func (c *GeminiChat) buildRequest(messages []Message, opts *ChatOptions) geminiRequest {
+	req := geminiRequest{Contents: make([]geminiContent, 0, len(messages))}
+	if opts != nil {
+		req.GenerationConfig = geminiGenerationConfigFrom(opts)
+		req.Tools, req.ToolConfig = geminiTools(opts)
+	}
+
+	var systemParts []geminiPart
+	// toolNames resolves the function name a tool result answers, which
+	// functionResponse requires but tool messages may not carry.
+	toolNames := make(map[string]string)
+	for _, msg := range messages {
+		content := strings.TrimSpace(msg.Content)
+		if content == "" {
+			content = textFromMultiContent(msg.MultiContent)
+		}
+		switch msg.Role {
+		case "system":
+			if content != "" {
+				systemParts = append(systemParts, geminiPart{Text: content})
+			}
+		case "assistant":
+			var parts []geminiPart
+			if content != "" {
+				parts = append(parts, geminiPart{Text: content})
+			}
+			for _, tc := range msg.ToolCalls {
+				toolNames[tc.ID] = tc.Function.Name
+				args := json.RawMessage(tc.Function.Arguments)
+				if !json.Valid(args) {
+					args = json.RawMessage("{}")
+				}
+				parts = append(parts, geminiPart{
+					FunctionCall:     &geminiFunctionCall{Name: tc.Function.Name, Args: args},
+					ThoughtSignature: geminiThoughtSignature(tc.ProviderMetadata),
+				})
+			}
+			if len(parts) > 0 {
+				req.Contents = append(req.Contents, geminiContent{Role: "model", Parts: parts})
+			}
+		case "tool":
+			name := msg.Name
+			if name == "" {
+				name = toolNames[msg.ToolCallID]
+			}
+			// The responses to the calls of one model turn go back together
+			// in the next user turn.
+			part := geminiPart{FunctionResponse: &geminiFunctionResponse{
+				Name:     name,
+				Response: geminiFunctionResult(content),
+			}}
+			if n := len(req.Contents); n > 0 && isGeminiFunctionResponseTurn(req.Contents[n-1]) {
+				req.Contents[n-1].Parts = append(req.Contents[n-1].Parts, part)
+				continue
+			}
+			req.Contents = append(req.Contents, geminiContent{Role: "user", Parts: []geminiPart{part}})
+		default:
+			parts := geminiImageParts(msg)
+			if content != "" {
+				parts = append(parts, geminiPart{Text: content})
+			}
+			if len(parts) > 0 {
+				req.Contents = append(req.Contents, geminiContent{Role: "user", Parts: parts})
+			}
+		}
+	}
+	if len(systemParts) > 0 {
+		req.SystemInstruction = &geminiContent{Parts: systemParts}
+	}
+	return req
+}
+
+func geminiGenerationConfigFrom(opts *ChatOptions) *geminiGenerationConfig {
+	cfg := &geminiGenerationConfig{
+		Seed:             opts.Seed,
+		PresencePenalty:  opts.PresencePenalty,
+		FrequencyPenalty: opts.FrequencyPenalty,
+	}
+	if opts.Temperature > 0 {
+		temperature := opts.Temperature
+		cfg.Temperature = &temperature
+	}
+	if opts.TopP > 0 {
+		topP := opts.TopP
+		cfg.TopP = &topP
+	}
+	if opts.MaxTokens > 0 {
+		cfg.MaxOutputTokens = opts.MaxTokens
+	} else if opts.MaxCompletionTokens > 0 {
+		cfg.MaxOutputTokens = opts.MaxCompletionTokens
+	}
+	if len(opts.Format) > 0 && len(opts.Tools) == 0 {
+		cfg.ResponseMimeType = "application/json"
+		cfg.ResponseJSONSchema = opts.Format
+	}
+	if opts.Thinking != nil && *opts.Thinking {
+		cfg.ThinkingConfig = &geminiThinkingConfig{IncludeThoughts: true}
+	}
+	return cfg
+}
+
+// geminiTools maps the tools and tool choice of opts to function
+// declarations and a function calling config.
+func geminiTools(opts *ChatOptions) ([]geminiTool, *geminiToolConfig) {
+	if len(opts.Tools) == 0 {
+		return nil, nil
+	}
+	declarations := make([]geminiFunctionDeclaration, 0, len(opts.Tools))
+	for _, tool := range opts.Tools {
+		declarations = append(declarations, geminiFunctionDeclaration{
+			Name:                 tool.Function.Name,
+			Description:          tool.Function.Description,
+			ParametersJSONSchema: tool.Function.Parameters,
+		})
+	}
+	tools := []geminiTool{{FunctionDeclarations: declarations}}
+
+	switch opts.ToolChoice {
+	case "", "auto":
+		return tools, nil
+	case "required":
+		return tools, &geminiToolConfig{FunctionCallingConfig: geminiFunctionCallingConfig{Mode: "ANY"}}
+	case "none":
+		return tools, &geminiToolConfig{FunctionCallingConfig: geminiFunctionCallingConfig{Mode: "NONE"}}
+	default:
+		return tools, &geminiToolConfig{FunctionCallingConfig: geminiFunctionCallingConfig{
+			Mode:                 "ANY",
+			AllowedFunctionNames: []string{opts.ToolChoice},
+		}}
+	}
+}
+
+// geminiFunctionResult wraps a tool result as the JSON object a
+// functionResponse carries.
+func geminiFunctionResult(content string) json.RawMessage {
+	trimmed := strings.TrimSpace(content)
+	if strings.HasPrefix(trimmed, "{") && json.Valid([]byte(trimmed)) {
+		return json.RawMessage(trimmed)
+	}
+	result, _ := json.Marshal(map[string]string{"result": content})
+	return result
+}
+
+func isGeminiFunctionResponseTurn(content geminiContent) bool {
+	return content.Role == "user" && len(content.Parts) > 0 && content.Parts[0].FunctionResponse != nil
+}
+
+func geminiThoughtSignature(metadata types.ToolCallMetadata) string {
+	var signature string
+	if raw, ok := metadata[geminiThoughtSignatureKey]; ok {
+		_ = json.Unmarshal(raw, &signature)
+	}
+	return signature
+}
+
+// geminiImageParts returns the image parts of a user message, taken from its
+// multi-content parts or its attached images.
+func geminiImageParts(msg Message) []geminiPart {
+	var urls []string
+	for _, part := range msg.MultiContent {
+		if part.Type == "image_url" && part.ImageURL != nil {
+			urls = append(urls, part.ImageURL.URL)
+		}
+	}
+	if len(msg.MultiContent) == 0 {
+		urls = msg.Images
+	}
+	parts := make([]geminiPart, 0, len(urls))
+	for _, u := range urls {
+		resolved := resolveImageURLForLLM(u)
+		if mimeType, data, ok := splitDataURI(resolved); ok {
+			parts = append(parts, geminiPart{InlineData: &geminiBlob{MimeType: mimeType, Data: data}})
+		} else if strings.HasPrefix(resolved, "http://") || strings.HasPrefix(resolved, "https://") {
+			parts = append(parts, geminiPart{FileData: &geminiFileData{
+				MimeType: imageMimeType(resolved),
+				FileURI:  resolved,
+			}})
+		}
+	}
+	return parts
+}
+
+// imageMimeType guesses the MIME type of an image URL from its extension.
+func imageMimeType(imageURL string) string {
+	if u, err := url.Parse(imageURL); err == nil {
+		if mimeType := mime.TypeByExtension(path.Ext(u.Path)); strings.HasPrefix(mimeType, "image/") {
+			return mimeType
+		}
+	}
+	return "image/jpeg"
+}
+
+// geminiStreamState accumulates the answer of a generateContent response or
+// of the chunks of a streamed one.
+type geminiStreamState struct {
+	content     strings.Builder
+	reasoning   strings.Builder
+	toolCalls   []types.LLMToolCall
+	usage       *types.TokenUsage
+	stopReason  string
+	blockReason string
+}
+
+// geminiDelta is a piece of a response chunk, in the order it arrived.
+type geminiDelta struct {
+	thought  bool
+	text     string
+	toolCall *types.LLMToolCall
+}
+
+// apply folds a response chunk into the state and returns its pieces.
+func (s *geminiStreamState) apply(resp *geminiResponse) []geminiDelta {
+	if usage := resp.UsageMetadata; usage != nil {
+		s.usage = &types.TokenUsage{
+			PromptTokens:     usage.PromptTokenCount,
+			CompletionTokens: usage.CandidatesTokenCount + usage.ThoughtsTokenCount,
+			TotalTokens:      usage.TotalTokenCount,
+			CachedTokens:     usage.CachedContentTokenCount,
+		}
+	}
+	if resp.PromptFeedback != nil && resp.PromptFeedback.BlockReason != "" {
+		s.blockReason = resp.PromptFeedback.BlockReason
+	}
+	if len(resp.Candidates) == 0 {
+		return nil
+	}
+	candidate := resp.Candidates[0]
+	if candidate.FinishReason != "" {
+		s.stopReason = candidate.FinishReason
+	}
+
+	var deltas []geminiDelta
+	for _, part := range candidate.Content.Parts {
+		switch {
+		case part.FunctionCall != nil:
+			call := types.LLMToolCall{
+				ID:   part.FunctionCall.ID,
+				Type: "function",
+				Function: types.FunctionCall{
+					Name:      part.FunctionCall.Name,
+					Arguments: string(part.FunctionCall.Args),
+				},
+			}
+			if call.ID == "" {
+				call.ID = "call_" + uuid.NewString()
+			}
+			if call.Function.Arguments == "" {
+				call.Function.Arguments = "{}"
+			}
+			if part.ThoughtSignature != "" {
+				signature, _ := json.Marshal(part.ThoughtSignature)
+				call.ProviderMetadata = types.ToolCallMetadata{geminiThoughtSignatureKey: signature}
+			}
+			s.toolCalls = append(s.toolCalls, call)
+			deltas = append(deltas, geminiDelta{toolCall: &call})
+		case part.Text == "":
+		case part.Thought:
+			s.reasoning.WriteString(part.Text)
+			deltas = append(deltas, geminiDelta{thought: true, text: part.Text})
+		default:
+			s.content.WriteString(part.Text)
+			deltas = append(deltas, geminiDelta{text: part.Text})
+		}
+	}
+	return deltas
+}
+
+// finishReason maps the Gemini finish reason to the OpenAI finish reason
+// callers branch on.
+func (s *geminiStreamState) finishReason() string {
+	if s.stopReason == "" && s.blockReason != "" {
+		return "content_filter"
+	}
+	switch s.stopReason {
+	case "STOP":
+		if len(s.toolCalls) > 0 {
+			return "tool_calls"
+		}
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return "content_filter"
+	default:
+		return strings.ToLower(s.stopReason)
+	}
+}
+
+func processGeminiStream(ctx context.Context, model string, resp *http.Response, streamChan chan types.StreamResponse) {
+	defer close(streamChan)
+	defer resp.Body.Close()
+
+	sseReader := NewSSEReader(resp.Body)
+	state := &geminiStreamState{}
+	var thinking thinkingEmitter
+
+	for {
+		event, err := sseReader.ReadEvent()
+		if err == io.EOF || (err == nil && event != nil && event.Done) {
+			thinking.finish(streamChan)
+			logUsage(ctx, model, state.usage)
+			streamChan <- types.StreamResponse{
+				ResponseType: types.ResponseTypeAnswer,
+				Content:      "",
+				Done:         true,
+				ToolCalls:    state.toolCalls,
+				Usage:        state.usage,
+				FinishReason: state.finishReason(),
+			}
+			return
+		}
+		if err != nil {
+			streamChan <- types.StreamResponse{
+				ResponseType: types.ResponseTypeError,
+				Content:      err.Error(),
+				Done:         true,
+			}
+			return
+		}
+		if event == nil || len(event.Data) == 0 {
+			continue
+		}
+
+		var chunk geminiResponse
+		if err := json.Unmarshal(event.Data, &chunk); err != nil {
+			streamChan <- types.StreamResponse{
+				ResponseType: types.ResponseTypeError,
+				Content:      fmt.Sprintf("decode SSE response: %v", err),
+				Done:         true,
+			}
+			return
+		}
+		if chunk.Error != nil && chunk.Error.Message != "" {
+			streamChan <- types.StreamResponse{
+				ResponseType: types.ResponseTypeError,
+				Content:      chunk.Error.Message,
+				Done:         true,
+			}
+			return
+		}
+		for _, delta := range state.apply(&chunk) {
+			switch {
+			case delta.toolCall != nil:
+				streamChan <- types.StreamResponse{
+					ResponseType: types.ResponseTypeToolCall,
+					Content:      "",
+					Done:         false,
+					Data: map[string]interface{}{
+						"tool_name":    delta.toolCall.Function.Name,
+						"tool_call_id": delta.toolCall.ID,
+					},
+				}
+			case delta.thought:
+				thinking.emit(streamChan, delta.text)
+			default:
+				thinking.finish(streamChan)
+				streamChan <- types.StreamResponse{
+					ResponseType: types.ResponseTypeAnswer,
+					Content:      delta.text,
+					Done:         false,
+				}
+			}
+		}
+	}
+}
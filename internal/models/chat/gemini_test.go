@@ -0,0 +1,156 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGeminiChat(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+
+	var capturedHeaders http.Header
+	var capturedRequest geminiRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1beta/models/gemini-2.5-flash:generateContent", r.URL.Path)
+		capturedHeaders = r.Header.Clone()
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&capturedRequest))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{
+			"candidates":[{"content":{"role":"model","parts":[
+				{"functionCall":{"name":"search","args":{"q":"leave"}},"thoughtSignature":"sig-2"}
+			]},"finishReason":"STOP"}],
+			"usageMetadata":{"promptTokenCount":10,"candidatesTokenCount":4,"thoughtsTokenCount":2,"totalTokenCount":16}
+		}`))
+	}))
+	defer server.Close()
+
+	chat, err := NewGeminiChat(&ChatConfig{
+		BaseURL:   server.URL + "/v1beta",
+		ModelName: "models/gemini-2.5-flash",
+		APIKey:    "test-key",
+	})
+	require.NoError(t, err)
+
+	signature, _ := json.Marshal("sig-1")
+	resp, err := chat.Chat(context.Background(), []Message{
+		{Role: "system", Content: "You are helpful."},
+		{Role: "user", Content: "How much leave?", Images: []string{"data:image/png;base64,AAAA"}},
+		{Role: "assistant", ToolCalls: []ToolCall{{
+			ID: "call_1", Type: "function", Function: FunctionCall{Name: "search", Arguments: `{"q":"annual"}`},
+			ProviderMetadata: types.ToolCallMetadata{geminiThoughtSignatureKey: signature},
+		}}},
+		{Role: "tool", ToolCallID: "call_1", Content: "20 days"},
+	}, &ChatOptions{
+		Temperature: 0.2,
+		MaxTokens:   100,
+		Tools:       []Tool{{Type: "function", Function: FunctionDef{Name: "search", Parameters: json.RawMessage(`{"type":"object"}`)}}},
+		ToolChoice:  "required",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "test-key", capturedHeaders.Get("x-goog-api-key"))
+	require.NotNil(t, capturedRequest.SystemInstruction)
+	assert.Equal(t, "You are helpful.", capturedRequest.SystemInstruction.Parts[0].Text)
+	require.Len(t, capturedRequest.Contents, 3)
+	user := capturedRequest.Contents[0]
+	assert.Equal(t, "user", user.Role)
+	assert.Equal(t, &geminiBlob{MimeType: "image/png", Data: "AAAA"}, user.Parts[0].InlineData)
+	assert.Equal(t, "How much leave?", user.Parts[1].Text)
+	model := capturedRequest.Contents[1]
+	assert.Equal(t, "model", model.Role)
+	assert.Equal(t, "search", model.Parts[0].FunctionCall.Name)
+	assert.Equal(t, "sig-1", model.Parts[0].ThoughtSignature)
+	response := capturedRequest.Contents[2].Parts[0].FunctionResponse
+	require.NotNil(t, response)
+	assert.Equal(t, "search", response.Name, "the name is resolved from the tool call")
+	assert.JSONEq(t, `{"result":"20 days"}`, string(response.Response))
+	assert.Equal(t, "ANY", capturedRequest.ToolConfig.FunctionCallingConfig.Mode)
+	assert.Equal(t, 100, capturedRequest.GenerationConfig.MaxOutputTokens)
+
+	require.Len(t, resp.ToolCalls, 1)
+	assert.NotEmpty(t, resp.ToolCalls[0].ID)
+	assert.JSONEq(t, `{"q":"leave"}`, resp.ToolCalls[0].Function.Arguments)
+	assert.Equal(t, "sig-2", geminiThoughtSignature(resp.ToolCalls[0].ProviderMetadata))
+	assert.Equal(t, "tool_calls", resp.FinishReason)
+	assert.Equal(t, 10, resp.Usage.PromptTokens)
+	assert.Equal(t, 6, resp.Usage.CompletionTokens)
+	assert.Equal(t, 16, resp.Usage.TotalTokens)
+}
+
+func TestGeminiChat_StructuredOutput(t *testing.T) {
+	chat := &GeminiChat{modelName: "gemini-2.5-flash"}
+	schema := json.RawMessage(`{"type":"object","properties":{"answer":{"type":"string"}}}`)
+	req := chat.buildRequest([]Message{{Role: "user", Content: "Is it?"}}, &ChatOptions{Format: schema})
+
+	assert.Equal(t, "application/json", req.GenerationConfig.ResponseMimeType)
+	assert.JSONEq(t, string(schema), string(req.GenerationConfig.ResponseJSONSchema))
+	assert.Empty(t, req.Tools)
+}
+
+func TestGeminiChat_ChatStream(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/models/gemini-2.5-flash:streamGenerateContent", r.URL.Path)
+		assert.Equal(t, "sse", r.URL.Query().Get("alt"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(`data: {"candidates":[{"content":{"role":"model","parts":[{"text":"Thinking it over","thought":true}]}}]}
+
+data: {"candidates":[{"content":{"role":"model","parts":[{"text":"po"}]}}]}
+
+data: {"candidates":[{"content":{"role":"model","parts":[{"text":"ng"}]},"finishReason":"STOP"}],"usageMetadata":{"promptTokenCount":7,"candidatesTokenCount":2,"totalTokenCount":9}}
+
+`))
+	}))
+	defer server.Close()
+
+	chat, err := NewGeminiChat(&ChatConfig{BaseURL: server.URL, ModelName: "gemini-2.5-flash", APIKey: "test-key"})
+	require.NoError(t, err)
+
+	thinking := true
+	ch, err := chat.ChatStream(context.Background(), []Message{{Role: "user", Content: "ping"}},
+		&ChatOptions{Thinking: &thinking})
+	require.NoError(t, err)
+
+	var chunks []types.StreamResponse
+	for chunk := range ch {
+		chunks = append(chunks, chunk)
+	}
+
+	require.Len(t, chunks, 5)
+	assert.Equal(t, types.ResponseTypeThinking, chunks[0].ResponseType)
+	assert.Equal(t, "Thinking it over", chunks[0].Content)
+	assert.Equal(t, types.ResponseTypeThinking, chunks[1].ResponseType)
+	assert.True(t, chunks[1].Done)
+	assert.Equal(t, "po", chunks[2].Content)
+	assert.Equal(t, "ng", chunks[3].Content)
+	last := chunks[4]
+	assert.True(t, last.Done)
+	assert.Equal(t, "stop", last.FinishReason)
+	require.NotNil(t, last.Usage)
+	assert.Equal(t, 9, last.Usage.TotalTokens)
+}
+
+func TestNewRemoteChat_GeminiProvider(t *testing.T) {
+	native, err := NewRemoteChat(&ChatConfig{
+		Source:    types.ModelSourceRemote,
+		ModelName: "gemini-2.5-flash",
+		APIKey:    "test-key",
+		Provider:  string(provider.ProviderGemini),
+	})
+	require.NoError(t, err)
+	assert.IsType(t, &GeminiChat{}, native)
+
+	assert.True(t, isGeminiOpenAICompatURL(provider.GeminiOpenAICompatBaseURL))
+	assert.True(t, isGeminiOpenAICompatURL(provider.GeminiOpenAICompatBaseURL+"/"))
+	assert.False(t, isGeminiOpenAICompatURL(provider.GeminiBaseURL))
+}
@@ -27,6 +27,20 @@ func resolveImageURLForLLM(imageURL string) string {
 	return imageURL
 }
 
+// splitDataURI splits a base64 data URI into its MIME type and payload, for
+// the native APIs that take inline images as separate fields.
+func splitDataURI(uri string) (mimeType, data string, ok bool) {
+	rest, found := strings.CutPrefix(uri, "data:")
+	if !found {
+		return "", "", false
+	}
+	mimeType, data, found = strings.Cut(rest, ";base64,")
+	if !found || mimeType == "" || data == "" {
+		return "", "", false
+	}
+	return mimeType, data, true
+}
+
 // resolveImageURLForOllama converts stored image paths to raw bytes for the Ollama API.
 func resolveImageURLForOllama(imageURL string) []byte {
 	if strings.HasPrefix(imageURL, "data:") {
@@ -18,6 +18,7 @@ type langfuseChat struct {
 
 func (l *langfuseChat) GetModelName() string { return l.inner.GetModelName() }
 func (l *langfuseChat) GetModelID() string   { return l.inner.GetModelID() }
+func (l *langfuseChat) Unwrap() Chat         { return l.inner }
 
 func (l *langfuseChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
 	mgr := langfuse.GetManager()
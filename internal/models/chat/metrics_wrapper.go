@@ -0,0 +1,59 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// metricsChat wraps a Chat and reports the latency of every call to
+// Prometheus. Streams are timed until their last chunk. It sits below the
+// response cache, so cache hits are not counted as model calls.
+type metricsChat struct {
+	inner Chat
+}
+
+func (m *metricsChat) GetModelName() string { return m.inner.GetModelName() }
+func (m *metricsChat) GetModelID() string   { return m.inner.GetModelID() }
+func (m *metricsChat) Unwrap() Chat         { return m.inner }
+
+func (m *metricsChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	start := time.Now()
+	resp, err := m.inner.Chat(ctx, messages, opts)
+	metrics.ObserveModelCall(ctx, types.TokenUsageKindChat, m.inner.GetModelName(), start, err)
+	return resp, err
+}
+
+func (m *metricsChat) ChatStream(ctx context.Context, messages []Message, opts *ChatOptions) (<-chan types.StreamResponse, error) {
+	start := time.Now()
+	ch, err := m.inner.ChatStream(ctx, messages, opts)
+	if err != nil || ch == nil {
+		metrics.ObserveModelCall(ctx, types.TokenUsageKindChat, m.inner.GetModelName(), start, err)
+		return ch, err
+	}
+
+	wrapped := make(chan types.StreamResponse)
+	go func() {
+		defer close(wrapped)
+		var streamErr error
+		for resp := range ch {
+			if resp.ResponseType == types.ResponseTypeError {
+				streamErr = errors.New(resp.Content)
+			}
+			wrapped <- resp
+		}
+		metrics.ObserveModelCall(ctx, types.TokenUsageKindChat, m.inner.GetModelName(), start, streamErr)
+	}()
+	return wrapped, nil
+}
+
+// wrapChatMetrics wraps a Chat so its latency is reported.
+func wrapChatMetrics(c Chat, err error) (Chat, error) {
+	if err != nil || c == nil {
+		return c, err
+	}
+	return &metricsChat{inner: c}, nil
+}
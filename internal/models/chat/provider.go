@@ -213,14 +213,18 @@ func (azureProvider) Name() provider.ProviderName { return provider.ProviderAzur
 func (azureProvider) Auth(req *http.Request, creds authCreds, _ []byte) {
 	req.Header.Set("api-key", creds.APIKey)
 }
+func (azureProvider) ShapeRequest(req *openai.ChatCompletionRequest, opts *ChatOptions, _ bool) {
+	shapeJSONSchemaFormat(req, opts)
+}
 
 type azureReasoningProvider struct{ azureProvider }
 
 func (azureReasoningProvider) Matches(model string) bool {
 	return provider.IsOpenAIReasoningOrGPT5Model(model)
 }
-func (azureReasoningProvider) ShapeRequest(req *openai.ChatCompletionRequest, _ *ChatOptions, _ bool) {
+func (azureReasoningProvider) ShapeRequest(req *openai.ChatCompletionRequest, opts *ChatOptions, _ bool) {
 	shapeOpenAIReasoning(req)
+	shapeJSONSchemaFormat(req, opts)
 }
 
 // --- OpenAI reasoning / GPT-5: no sampling params, must use max_completion_tokens ---
@@ -231,8 +235,18 @@ func (openAIReasoningProvider) Name() provider.ProviderName { return provider.Pr
 func (openAIReasoningProvider) Matches(model string) bool {
 	return provider.IsOpenAIReasoningOrGPT5Model(model)
 }
-func (openAIReasoningProvider) ShapeRequest(req *openai.ChatCompletionRequest, _ *ChatOptions, _ bool) {
+func (openAIReasoningProvider) ShapeRequest(req *openai.ChatCompletionRequest, opts *ChatOptions, _ bool) {
 	shapeOpenAIReasoning(req)
+	shapeJSONSchemaFormat(req, opts)
+}
+
+// --- OpenAI: native json_schema response format ---
+
+type openAIProvider struct{ baseProvider }
+
+func (openAIProvider) Name() provider.ProviderName { return provider.ProviderOpenAI }
+func (openAIProvider) ShapeRequest(req *openai.ChatCompletionRequest, opts *ChatOptions, _ bool) {
+	shapeJSONSchemaFormat(req, opts)
 }
 
 // --- Moonshot: v1 models accept only temperature=1 ---
@@ -265,6 +279,29 @@ func shapeOpenAIReasoning(req *openai.ChatCompletionRequest) {
 	req.MaxTokens = 0
 }
 
+// shapeJSONSchemaFormat upgrades the json_object response format of a JSON
+// schema (ChatOptions.Format) to the native json_schema format, so OpenAI
+// enforces the schema instead of only reading it from the prompt. The API
+// requires an object at the root; other schemas keep json_object.
+func shapeJSONSchemaFormat(req *openai.ChatCompletionRequest, opts *ChatOptions) {
+	if opts == nil || len(opts.Format) == 0 || req.ResponseFormat == nil {
+		return
+	}
+	var root struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(opts.Format, &root); err != nil || root.Type != "object" {
+		return
+	}
+	req.ResponseFormat = &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:   "structured_output",
+			Schema: opts.Format,
+		},
+	}
+}
+
 // providerRegistry is ordered: more specific adapters (those with a real
 // Matches predicate) must precede the generic catch-all for the same provider.
 var providerRegistry = []providerAdapter{
@@ -279,6 +316,7 @@ var providerRegistry = []providerAdapter{
 	azureReasoningProvider{},
 	azureProvider{},
 	openAIReasoningProvider{},
+	openAIProvider{},
 	moonshotProvider{},
 }
 
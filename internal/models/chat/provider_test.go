@@ -29,7 +29,7 @@ func TestResolveProvider(t *testing.T) {
 		{"gemini", provider.ProviderGemini, "gemini-3-flash-preview", geminiProvider{}},
 		{"nvidia", provider.ProviderNvidia, "anything", nvidiaProvider{}},
 		{"volcengine", provider.ProviderVolcengine, "doubao", volcengineProvider{}},
-		{"openai non-reasoning falls back", provider.ProviderOpenAI, "gpt-4o", baseProvider{}},
+		{"openai non-reasoning", provider.ProviderOpenAI, "gpt-4o", openAIProvider{}},
 		{"openai reasoning", provider.ProviderOpenAI, "gpt-5", openAIReasoningProvider{}},
 		{"azure non-reasoning", provider.ProviderAzureOpenAI, "gpt-4", azureProvider{}},
 		{"azure reasoning", provider.ProviderAzureOpenAI, "gpt-5-mini", azureReasoningProvider{}},
@@ -160,6 +160,24 @@ func TestBuildOutbound_ShapeRequest(t *testing.T) {
 		assert.EqualValues(t, 1, req.Temperature)
 		assert.EqualValues(t, 0, req.TopP)
 	})
+
+	t.Run("openai enforces object schemas natively", func(t *testing.T) {
+		c := newOutboundChat(t, string(provider.ProviderOpenAI), "gpt-4o", nil)
+		schema := json.RawMessage(`{"type":"object","properties":{"ok":{"type":"boolean"}}}`)
+		body, _, _, err := c.buildOutbound(msgs, &ChatOptions{Format: schema}, false)
+		require.NoError(t, err)
+		req := body.(*openai.ChatCompletionRequest)
+		require.NotNil(t, req.ResponseFormat)
+		assert.Equal(t, openai.ChatCompletionResponseFormatTypeJSONSchema, req.ResponseFormat.Type)
+		require.NotNil(t, req.ResponseFormat.JSONSchema)
+		assert.Equal(t, schema, req.ResponseFormat.JSONSchema.Schema)
+
+		// The API only takes object schemas; others stay on json_object.
+		body, _, _, err = c.buildOutbound(msgs, &ChatOptions{Format: json.RawMessage(`{"type":"array"}`)}, false)
+		require.NoError(t, err)
+		req = body.(*openai.ChatCompletionRequest)
+		assert.Equal(t, openai.ChatCompletionResponseFormatTypeJSONObject, req.ResponseFormat.Type)
+	})
 }
 
 func TestBuildOutbound_GeminiProviderMetadata(t *testing.T) {
@@ -31,6 +31,8 @@ type RemoteAPIChat struct {
 	appSecret string
 	// customHeaders 为用户在模型配置中指定的自定义 HTTP 请求头（类似 OpenAI Python SDK 的 extra_headers）。
 	customHeaders map[string]string
+	// chatEndpoint 为 raw HTTP 路径的默认地址（Azure OpenAI 按部署和 api-version 拼接），为空时取 baseURL + "/chat/completions"。
+	chatEndpoint string
 
 	// adapter 承载所有 provider 特定行为（thinking / 参数特判 / endpoint / 鉴权 / 消息变换）。
 	adapter providerAdapter
@@ -52,17 +54,35 @@ func NewRemoteAPIChat(chatConfig *ChatConfig) (*RemoteAPIChat, error) {
 		providerName = provider.DetectProvider(chatConfig.BaseURL)
 	}
 
-	var config openai.ClientConfig
-	if providerName == provider.ProviderAzureOpenAI {
-		config = openai.DefaultAzureConfig(apiKey, chatConfig.BaseURL)
-		config.AzureModelMapperFunc = func(model string) string {
-			return model
+	modelName := chatConfig.ModelName
+	if chatConfig.ExtraConfig != nil {
+		if override := strings.TrimSpace(chatConfig.ExtraConfig["remote_model_name"]); override != "" {
+			modelName = override
 		}
-		if chatConfig.ExtraConfig != nil {
-			if v, ok := chatConfig.ExtraConfig["api_version"]; ok {
-				config.APIVersion = v
+	}
+	// adapterModel 是用于匹配 provider 特判的模型名；Azure v1 API 的请求 model 为部署名，
+	// 但推理模型等特判仍按实际模型名判断。
+	adapterModel := modelName
+
+	var (
+		config       openai.ClientConfig
+		chatEndpoint string
+	)
+	if providerName == provider.ProviderAzureOpenAI {
+		deployment := provider.AzureOpenAIDeployment(modelName, chatConfig.ExtraConfig)
+		apiVersion := provider.AzureOpenAIAPIVersion(chatConfig.ExtraConfig)
+		if apiVersion == provider.AzureOpenAIV1APIVersion {
+			config = openai.DefaultConfig(apiKey)
+			config.BaseURL = provider.AzureOpenAIURL(chatConfig.BaseURL, "", apiVersion, "")
+			modelName = deployment
+		} else {
+			config = openai.DefaultAzureConfig(apiKey, provider.AzureOpenAIEndpoint(chatConfig.BaseURL))
+			config.APIVersion = apiVersion
+			config.AzureModelMapperFunc = func(string) string {
+				return deployment
 			}
 		}
+		chatEndpoint = provider.AzureOpenAIURL(chatConfig.BaseURL, deployment, apiVersion, "chat/completions")
 	} else {
 		config = openai.DefaultConfig(apiKey)
 		if baseURL := chatConfig.BaseURL; baseURL != "" {
@@ -81,12 +101,6 @@ func NewRemoteAPIChat(chatConfig *ChatConfig) (*RemoteAPIChat, error) {
 		}
 	}
 
-	modelName := chatConfig.ModelName
-	if chatConfig.ExtraConfig != nil {
-		if override := strings.TrimSpace(chatConfig.ExtraConfig["remote_model_name"]); override != "" {
-			modelName = override
-		}
-	}
 	if providerName == provider.ProviderWeKnoraCloud {
 		if chatConfig.AppID == "" {
 			return nil, fmt.Errorf("WeKnoraCloud provider: AppID is required")
@@ -101,12 +115,13 @@ func NewRemoteAPIChat(chatConfig *ChatConfig) (*RemoteAPIChat, error) {
 		client:           openai.NewClientWithConfig(config),
 		modelID:          chatConfig.ModelID,
 		baseURL:          chatConfig.BaseURL,
+		chatEndpoint:     chatEndpoint,
 		apiKey:           apiKey,
 		provider:         providerName,
 		appID:            chatConfig.AppID,
 		appSecret:        chatConfig.AppSecret,
 		customHeaders:    chatConfig.CustomHeaders,
-		adapter:          resolveProvider(providerName, modelName),
+		adapter:          resolveProvider(providerName, adapterModel),
 		thinkingOverride: parseThinkingOverride(chatConfig.ExtraConfig),
 	}, nil
 }
@@ -153,6 +168,14 @@ func (c *RemoteAPIChat) buildOutbound(
 	return body, endpoint, useRawHTTP, nil
 }
 
+// defaultEndpoint 返回 raw HTTP 路径在 adapter 未覆盖 endpoint 时使用的地址
+func (c *RemoteAPIChat) defaultEndpoint() string {
+	if c.chatEndpoint != "" {
+		return c.chatEndpoint
+	}
+	return c.baseURL + "/chat/completions"
+}
+
 // logRequest 记录请求日志
 func (c *RemoteAPIChat) logRequest(ctx context.Context, req any, isStream bool) {
 	if jsonData, err := json.MarshalIndent(req, "", "  "); err == nil {
@@ -207,7 +230,7 @@ func (c *RemoteAPIChat) chatWithRawHTTP(ctx context.Context, endpoint string, cu
 	}
 
 	if endpoint == "" {
-		endpoint = c.baseURL + "/chat/completions"
+		endpoint = c.defaultEndpoint()
 	}
 	if err := secutils.ValidateURLForSSRF(endpoint); err != nil {
 		return nil, fmt.Errorf("endpoint SSRF check failed: %w", err)
@@ -337,7 +360,7 @@ func (c *RemoteAPIChat) chatStreamWithRawHTTP(ctx context.Context, endpoint stri
 	}
 
 	if endpoint == "" {
-		endpoint = c.baseURL + "/chat/completions"
+		endpoint = c.defaultEndpoint()
 	}
 	if err := secutils.ValidateURLForSSRF(endpoint); err != nil {
 		return nil, fmt.Errorf("endpoint SSRF check failed: %w", err)
@@ -3,6 +3,8 @@ package chat
 import (
 	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"testing"
 	"time"
@@ -207,6 +209,66 @@ func TestBuildChatCompletionRequest_GPT5MaxCompletionTokens(t *testing.T) {
 	})
 }
 
+// TestRemoteAPIChat_AzureDeploymentRouting 验证 Azure OpenAI 按 deployment_name 路由，
+// 以及 api_version 为 v1 时改走 /openai/v1 且以部署名作为 model。
+func TestRemoteAPIChat_AzureDeploymentRouting(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+
+	var gotPath, gotVersion, gotModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotVersion = r.URL.Query().Get("api-version")
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		gotModel, _ = body["model"].(string)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name        string
+		extra       map[string]string
+		wantPath    string
+		wantVersion string
+		wantModel   string
+	}{
+		{
+			name:        "deployment path with default api version",
+			extra:       map[string]string{"deployment_name": "prod-gpt"},
+			wantPath:    "/openai/deployments/prod-gpt/chat/completions",
+			wantVersion: "2024-10-21",
+			wantModel:   "gpt-4o",
+		},
+		{
+			name:      "v1 api",
+			extra:     map[string]string{"deployment_name": "prod-gpt", "api_version": "v1"},
+			wantPath:  "/openai/v1/chat/completions",
+			wantModel: "prod-gpt",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := NewRemoteAPIChat(&ChatConfig{
+				Source:      types.ModelSourceRemote,
+				BaseURL:     server.URL,
+				ModelName:   "gpt-4o",
+				APIKey:      "test-key",
+				Provider:    "azure_openai",
+				ExtraConfig: tc.extra,
+			})
+			require.NoError(t, err)
+
+			resp, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, &ChatOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, "ok", resp.Content)
+			assert.Equal(t, tc.wantPath, gotPath)
+			assert.Equal(t, tc.wantVersion, gotVersion)
+			assert.Equal(t, tc.wantModel, gotModel)
+		})
+	}
+}
+
 func TestBuildChatCompletionRequest_ToolChoice(t *testing.T) {
 	chat := newTestRemoteChat(t)
 	messages := []Message{{Role: "user", Content: "test"}}
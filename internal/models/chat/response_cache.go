@@ -0,0 +1,222 @@
+package chat
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Response caching for deterministic internal calls.
+//
+// Internal features (entity/keyword extraction, query understanding and
+// routing, data-analysis SQL generation, session titles) often send the
+// exact same prompt to the same model again, e.g. when a user retries a
+// question or regenerates an answer. Those call sites opt in with
+// WithResponseCache; user-facing chat and streaming calls are never cached.
+//
+// The cache is process-wide because Chat instances are built per request.
+// It is tuned with:
+//
+//	WEKNORA_LLM_RESPONSE_CACHE_TTL          entry lifetime (default 1h, 0 disables)
+//	WEKNORA_LLM_RESPONSE_CACHE_MAX_ENTRIES  LRU capacity   (default 1000, 0 disables)
+
+const (
+	defaultResponseCacheTTL        = time.Hour
+	defaultResponseCacheMaxEntries = 1000
+)
+
+type responseCacheCtxKey struct{}
+
+// WithResponseCache marks the Chat calls made with the returned context as
+// cacheable. feature names the caller in logs. Only use it for prompts whose
+// answer is a pure function of the prompt: the cached response is returned
+// verbatim for an identical model, message list and options.
+func WithResponseCache(ctx context.Context, feature string) context.Context {
+	return context.WithValue(ctx, responseCacheCtxKey{}, feature)
+}
+
+func responseCacheFeature(ctx context.Context) (string, bool) {
+	feature, ok := ctx.Value(responseCacheCtxKey{}).(string)
+	return feature, ok
+}
+
+type responseCacheEntry struct {
+	key       string
+	resp      types.ChatResponse
+	expiresAt time.Time
+}
+
+// responseCache is a TTL-bounded LRU of chat responses keyed by prompt hash.
+type responseCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	order      *list.List // front = most recently used
+	entries    map[string]*list.Element
+	now        func() time.Time
+}
+
+func newResponseCache(ttl time.Duration, maxEntries int) *responseCache {
+	return &responseCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+func (c *responseCache) get(key string) (*types.ChatResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*responseCacheEntry)
+	if c.now().After(entry.expiresAt) {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	resp := entry.resp
+	return &resp, true
+}
+
+func (c *responseCache) put(key string, resp *types.ChatResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*responseCacheEntry)
+		entry.resp = *resp
+		entry.expiresAt = c.now().Add(c.ttl)
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&responseCacheEntry{
+		key:       key,
+		resp:      *resp,
+		expiresAt: c.now().Add(c.ttl),
+	})
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*responseCacheEntry).key)
+	}
+}
+
+var (
+	sharedResponseCache     *responseCache
+	sharedResponseCacheOnce sync.Once
+)
+
+// getResponseCache returns the process-wide cache, or nil when disabled.
+func getResponseCache() *responseCache {
+	sharedResponseCacheOnce.Do(func() {
+		ttl := defaultResponseCacheTTL
+		if v := strings.TrimSpace(os.Getenv("WEKNORA_LLM_RESPONSE_CACHE_TTL")); v != "" {
+			if v == "0" {
+				ttl = 0
+			} else if d, err := time.ParseDuration(v); err == nil {
+				ttl = d
+			} else {
+				logger.Warnf(context.Background(), "invalid WEKNORA_LLM_RESPONSE_CACHE_TTL %q, using %s", v, ttl)
+			}
+		}
+		maxEntries := defaultResponseCacheMaxEntries
+		if v := strings.TrimSpace(os.Getenv("WEKNORA_LLM_RESPONSE_CACHE_MAX_ENTRIES")); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+				maxEntries = n
+			} else {
+				logger.Warnf(context.Background(), "invalid WEKNORA_LLM_RESPONSE_CACHE_MAX_ENTRIES %q, using %d", v, maxEntries)
+			}
+		}
+		if ttl > 0 && maxEntries > 0 {
+			sharedResponseCache = newResponseCache(ttl, maxEntries)
+		}
+	})
+	return sharedResponseCache
+}
+
+// responseCacheKey hashes everything that determines the model's answer.
+func responseCacheKey(modelID, modelName string, messages []Message, opts *ChatOptions) (string, error) {
+	payload, err := json.Marshal(struct {
+		ModelID   string       `json:"model_id"`
+		ModelName string       `json:"model_name"`
+		Messages  []Message    `json:"messages"`
+		Options   *ChatOptions `json:"options"`
+	}{modelID, modelName, messages, opts})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// cachedChat serves opted-in non-streaming calls from the response cache.
+type cachedChat struct {
+	inner Chat
+	cache *responseCache
+}
+
+func (c *cachedChat) GetModelName() string { return c.inner.GetModelName() }
+func (c *cachedChat) GetModelID() string   { return c.inner.GetModelID() }
+func (c *cachedChat) Unwrap() Chat         { return c.inner }
+
+func (c *cachedChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	feature, ok := responseCacheFeature(ctx)
+	if !ok {
+		return c.inner.Chat(ctx, messages, opts)
+	}
+	key, err := responseCacheKey(c.inner.GetModelID(), c.inner.GetModelName(), messages, opts)
+	if err != nil {
+		return c.inner.Chat(ctx, messages, opts)
+	}
+	resp, hit := c.cache.get(key)
+	metrics.RecordCache(ctx, metrics.CacheLLMResponse, hit)
+	if hit {
+		logger.Debugf(ctx, "[LLM Cache] hit feature=%s model=%s saved_tokens=%d",
+			feature, c.inner.GetModelName(), resp.Usage.TotalTokens)
+		// No tokens were spent on this call.
+		resp.Usage = types.TokenUsage{}
+		return resp, nil
+	}
+
+	resp, err = c.inner.Chat(ctx, messages, opts)
+	if err != nil || resp == nil {
+		return resp, err
+	}
+	// Truncated or tool-calling answers are not worth replaying.
+	if resp.Content != "" && len(resp.ToolCalls) == 0 && resp.FinishReason != "length" {
+		c.cache.put(key, resp)
+	}
+	return resp, nil
+}
+
+func (c *cachedChat) ChatStream(ctx context.Context, messages []Message, opts *ChatOptions) (<-chan types.StreamResponse, error) {
+	return c.inner.ChatStream(ctx, messages, opts)
+}
+
+// wrapChatCache wraps a Chat with the response cache unless it is disabled.
+func wrapChatCache(c Chat, err error) (Chat, error) {
+	if err != nil || c == nil {
+		return c, err
+	}
+	cache := getResponseCache()
+	if cache == nil {
+		return c, nil
+	}
+	return &cachedChat{inner: c, cache: cache}, nil
+}
@@ -0,0 +1,97 @@
+package chat
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+type countingChat struct {
+	calls int
+	resp  types.ChatResponse
+}
+
+func (c *countingChat) Chat(context.Context, []Message, *ChatOptions) (*types.ChatResponse, error) {
+	c.calls++
+	resp := c.resp
+	return &resp, nil
+}
+
+func (c *countingChat) ChatStream(context.Context, []Message, *ChatOptions) (<-chan types.StreamResponse, error) {
+	return nil, nil
+}
+
+func (c *countingChat) GetModelName() string { return "m" }
+func (c *countingChat) GetModelID() string   { return "model-1" }
+
+func TestCachedChatOptIn(t *testing.T) {
+	inner := &countingChat{resp: types.ChatResponse{Content: "title", Usage: types.TokenUsage{TotalTokens: 42}}}
+	c := &cachedChat{inner: inner, cache: newResponseCache(time.Hour, 10)}
+	msgs := []Message{{Role: "user", Content: "hello"}}
+	opts := &ChatOptions{Temperature: 0.3}
+
+	// Calls without the marker always reach the model.
+	_, _ = c.Chat(context.Background(), msgs, opts)
+	_, _ = c.Chat(context.Background(), msgs, opts)
+	if inner.calls != 2 {
+		t.Fatalf("uncached calls: got %d, want 2", inner.calls)
+	}
+
+	ctx := WithResponseCache(context.Background(), "test")
+	first, _ := c.Chat(ctx, msgs, opts)
+	second, _ := c.Chat(ctx, msgs, opts)
+	if inner.calls != 3 {
+		t.Fatalf("cached calls: got %d model calls, want 3", inner.calls)
+	}
+	if first.Usage.TotalTokens != 42 || second.Usage.TotalTokens != 0 {
+		t.Fatalf("usage: first=%d second=%d, want 42 and 0", first.Usage.TotalTokens, second.Usage.TotalTokens)
+	}
+	if second.Content != "title" {
+		t.Fatalf("cached content: got %q", second.Content)
+	}
+
+	// A different option set is a different prompt.
+	_, _ = c.Chat(ctx, msgs, &ChatOptions{Temperature: 0.7})
+	if inner.calls != 4 {
+		t.Fatalf("options not part of key: got %d model calls, want 4", inner.calls)
+	}
+}
+
+func TestCachedChatSkipsTruncatedResponses(t *testing.T) {
+	inner := &countingChat{resp: types.ChatResponse{Content: "partial", FinishReason: "length"}}
+	c := &cachedChat{inner: inner, cache: newResponseCache(time.Hour, 10)}
+	ctx := WithResponseCache(context.Background(), "test")
+	msgs := []Message{{Role: "user", Content: "hello"}}
+
+	_, _ = c.Chat(ctx, msgs, nil)
+	_, _ = c.Chat(ctx, msgs, nil)
+	if inner.calls != 2 {
+		t.Fatalf("got %d model calls, want 2", inner.calls)
+	}
+}
+
+func TestResponseCacheTTLAndEviction(t *testing.T) {
+	now := time.Unix(0, 0)
+	cache := newResponseCache(time.Minute, 2)
+	cache.now = func() time.Time { return now }
+
+	cache.put("a", &types.ChatResponse{Content: "A"})
+	cache.put("b", &types.ChatResponse{Content: "B"})
+	if _, ok := cache.get("a"); !ok { // a becomes most recently used
+		t.Fatal("a should be cached")
+	}
+	cache.put("c", &types.ChatResponse{Content: "C"})
+	if _, ok := cache.get("b"); ok {
+		t.Fatal("b should have been evicted as least recently used")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.get("a"); ok {
+		t.Fatal("a should have expired")
+	}
+	if len(cache.entries) != 1 {
+		t.Fatalf("expired entry not removed: %d entries", len(cache.entries))
+	}
+}
@@ -0,0 +1,132 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/models/utils"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// SelfHostedChat 在自托管推理服务（vLLM、llama.cpp server）的多个副本间路由聊天请求：
+// 每次调用选择在途请求最少的健康副本，副本不可用时换下一个副本重试。
+// 各副本的请求由按 generic provider 配置的 RemoteAPIChat 发送。
+type SelfHostedChat struct {
+	modelID  string
+	pool     *utils.EndpointPool
+	replicas []*RemoteAPIChat
+}
+
+// NewSelfHostedChat 创建自托管推理服务聊天实例
+func NewSelfHostedChat(config *ChatConfig) (*SelfHostedChat, error) {
+	endpoints := provider.SelfHostedEndpoints(config.BaseURL, config.ExtraConfig)
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("base URL is required for self-hosted provider")
+	}
+
+	replicas := make([]*RemoteAPIChat, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		replicaConfig := *config
+		replicaConfig.BaseURL = endpoint
+		replicaConfig.Provider = string(provider.ProviderGeneric)
+		replica, err := NewRemoteAPIChat(&replicaConfig)
+		if err != nil {
+			return nil, fmt.Errorf("replica %s: %w", endpoint, err)
+		}
+		replicas = append(replicas, replica)
+	}
+
+	return &SelfHostedChat{
+		modelID:  config.ModelID,
+		pool:     utils.GetEndpointPool(endpoints, provider.SelfHostedHealthCheckPath(config.ExtraConfig)),
+		replicas: replicas,
+	}, nil
+}
+
+// Chat 进行非流式聊天
+func (c *SelfHostedChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	var (
+		tried   []int
+		lastErr error
+	)
+	for {
+		i, release, ok := c.pool.Acquire(tried)
+		if !ok {
+			return nil, lastErr
+		}
+		resp, err := c.replicas[i].Chat(ctx, messages, opts)
+		down := isReplicaDownError(ctx, err)
+		release(down)
+		if !down {
+			return resp, err
+		}
+		logger.Warnf(ctx, "[SelfHosted] replica %s of model %s unavailable, trying next: %v",
+			c.pool.BaseURL(i), c.GetModelName(), err)
+		tried = append(tried, i)
+		lastErr = err
+	}
+}
+
+// ChatStream 进行流式聊天；副本的在途计数持续到流结束
+func (c *SelfHostedChat) ChatStream(ctx context.Context, messages []Message, opts *ChatOptions) (<-chan types.StreamResponse, error) {
+	var (
+		tried   []int
+		lastErr error
+	)
+	for {
+		i, release, ok := c.pool.Acquire(tried)
+		if !ok {
+			return nil, lastErr
+		}
+		in, err := c.replicas[i].ChatStream(ctx, messages, opts)
+		if err == nil {
+			out := make(chan types.StreamResponse)
+			go func() {
+				defer release(false)
+				defer close(out)
+				for v := range in {
+					out <- v
+				}
+			}()
+			return out, nil
+		}
+		down := isReplicaDownError(ctx, err)
+		release(down)
+		if !down {
+			return nil, err
+		}
+		logger.Warnf(ctx, "[SelfHosted] replica %s of model %s unavailable, trying next: %v",
+			c.pool.BaseURL(i), c.GetModelName(), err)
+		tried = append(tried, i)
+		lastErr = err
+	}
+}
+
+// ContextLength 返回副本上报的上下文长度（vLLM max_model_len / llama.cpp n_ctx），无法获知时返回 0
+func (c *SelfHostedChat) ContextLength(ctx context.Context) int {
+	return c.pool.ContextLength(ctx, c.GetModelName())
+}
+
+// GetModelName 获取模型名称
+func (c *SelfHostedChat) GetModelName() string {
+	return c.replicas[0].GetModelName()
+}
+
+// GetModelID 获取模型ID
+func (c *SelfHostedChat) GetModelID() string {
+	return c.modelID
+}
+
+// isReplicaDownError 判断调用失败是否因为副本不可用（连接失败、超时、限流或服务端错误），
+// 此时应换其他副本重试并将该副本标记为不健康。
+func isReplicaDownError(ctx context.Context, err error) bool {
+	if IsFailoverError(ctx, err) {
+		return true
+	}
+	var opErr *net.OpError
+	return err != nil && ctx.Err() == nil && errors.As(err, &opErr)
+}
@@ -0,0 +1,84 @@
+package chat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSelfHostedReplica(t *testing.T, chatStatus int, answer string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/v1/models":
+			_, _ = w.Write([]byte(`{"data":[{"id":"qwen3-8b","max_model_len":8192}]}`))
+		case "/v1/chat/completions":
+			if chatStatus != http.StatusOK {
+				w.WriteHeader(chatStatus)
+				_, _ = w.Write([]byte(`{"error":{"message":"overloaded"}}`))
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"` + answer +
+				`"},"finish_reason":"stop"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSelfHostedChat_FailsOverToHealthyReplica(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+	down := newSelfHostedReplica(t, http.StatusServiceUnavailable, "")
+	up := newSelfHostedReplica(t, http.StatusOK, "from replica")
+
+	c, err := NewChat(&ChatConfig{
+		Source:      types.ModelSourceRemote,
+		BaseURL:     down.URL + "/v1",
+		ModelName:   "qwen3-8b",
+		Provider:    string(provider.ProviderSelfHosted),
+		ExtraConfig: map[string]string{"endpoints": up.URL + "/v1/, " + down.URL + "/v1"},
+	}, nil)
+	require.NoError(t, err)
+
+	for range 3 {
+		resp, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, &ChatOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "from replica", resp.Content)
+	}
+	assert.Equal(t, 8192, ContextLength(context.Background(), c))
+}
+
+func TestSelfHostedChat_AllReplicasDown(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+	a := newSelfHostedReplica(t, http.StatusBadGateway, "")
+	b := newSelfHostedReplica(t, http.StatusBadGateway, "")
+
+	c, err := NewSelfHostedChat(&ChatConfig{
+		BaseURL:     a.URL + "/v1",
+		ModelName:   "qwen3-8b",
+		ExtraConfig: map[string]string{"endpoints": b.URL + "/v1"},
+	})
+	require.NoError(t, err)
+	require.Len(t, c.replicas, 2)
+
+	_, err = c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, &ChatOptions{})
+	require.Error(t, err)
+	assert.True(t, IsFailoverError(context.Background(), err), "the model may still fail over to another model")
+}
+
+func TestContextLength_UnknownForOtherChats(t *testing.T) {
+	c, err := NewRemoteAPIChat(&ChatConfig{ModelName: "gpt-4o", APIKey: "key"})
+	require.NoError(t, err)
+	assert.Zero(t, ContextLength(context.Background(), c))
+}
@@ -0,0 +1,121 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/common"
+	"github.com/Tencent/WeKnora/internal/types"
+	jsonschema "github.com/google/jsonschema-go/jsonschema"
+)
+
+// DefaultStructuredOutputRepairs 是回答不符合 schema 时默认的修复重试次数
+const DefaultStructuredOutputRepairs = 2
+
+// structuredOutputRepairPrompt 要求模型按校验错误重新输出
+const structuredOutputRepairPrompt = "The previous response is not valid against the JSON schema: %s\n" +
+	"Respond again with only a JSON value that matches this schema, without any other text: %s"
+
+// StructuredOutputError 表示模型在修复重试后仍未返回符合 schema 的 JSON
+type StructuredOutputError struct {
+	Content  string // 最后一次回答
+	Problem  string // 最后一次解析或校验错误
+	Attempts int    // 调用模型的次数
+}
+
+// Error implements the error interface
+func (e *StructuredOutputError) Error() string {
+	return fmt.Sprintf("structured output does not match the schema after %d attempts: %s", e.Attempts, e.Problem)
+}
+
+// StructuredResult 是结构化输出的结果
+type StructuredResult struct {
+	// Response 是最后一次回答，Usage 为所有尝试之和
+	Response *types.ChatResponse
+	// Value 是通过校验的 JSON
+	Value json.RawMessage
+	// Attempts 是调用模型的次数
+	Attempts int
+}
+
+// CompileJSONSchema 解析 JSON Schema，schema 本身不合法时返回错误
+func CompileJSONSchema(schema json.RawMessage) (*jsonschema.Resolved, error) {
+	var s jsonschema.Schema
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	resolved, err := s.Resolve(nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	return resolved, nil
+}
+
+// ChatStructured 要求模型按 schema 输出 JSON 并在返回前校验。
+// 支持原生结构化输出的供应商（OpenAI、Azure OpenAI、Gemini、Anthropic、Bedrock、Ollama）
+// 通过 ChatOptions.Format 直接约束输出；其余供应商只在提示词中给出 schema。
+// 回答无法解析或不符合 schema 时，把错误反馈给模型重新生成，最多 maxRepairs 次。
+func ChatStructured(
+	ctx context.Context, model Chat, messages []Message, opts *ChatOptions, schema json.RawMessage, maxRepairs int,
+) (*StructuredResult, error) {
+	resolved, err := CompileJSONSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	if maxRepairs < 0 {
+		maxRepairs = 0
+	}
+
+	callOpts := ChatOptions{}
+	if opts != nil {
+		callOpts = *opts
+	}
+	callOpts.Format = schema
+	// 工具调用会让模型跳过结构化输出
+	callOpts.Tools = nil
+	callOpts.ToolChoice = ""
+
+	conversation := append([]Message(nil), messages...)
+	var usage types.TokenUsage
+	for attempt := 1; ; attempt++ {
+		resp, err := model.Chat(ctx, conversation, &callOpts)
+		if err != nil {
+			return nil, err
+		}
+		usage.PromptTokens += resp.Usage.PromptTokens
+		usage.CompletionTokens += resp.Usage.CompletionTokens
+		usage.TotalTokens += resp.Usage.TotalTokens
+		usage.CachedTokens += resp.Usage.CachedTokens
+
+		value, problem := validateStructuredOutput(resolved, resp.Content)
+		if problem == "" {
+			resp.Usage = usage
+			return &StructuredResult{Response: resp, Value: value, Attempts: attempt}, nil
+		}
+		if attempt > maxRepairs {
+			return nil, &StructuredOutputError{Content: resp.Content, Problem: problem, Attempts: attempt}
+		}
+		conversation = append(conversation,
+			Message{Role: "assistant", Content: resp.Content},
+			Message{Role: "user", Content: fmt.Sprintf(structuredOutputRepairPrompt, problem, schema)},
+		)
+	}
+}
+
+// validateStructuredOutput 解析回答中的 JSON（允许包在代码块中）并按 schema 校验，
+// 通过时返回规范化的 JSON，否则返回错误描述
+func validateStructuredOutput(resolved *jsonschema.Resolved, content string) (json.RawMessage, string) {
+	var value any
+	if err := common.ParseLLMJsonResponse(content, &value); err != nil {
+		return nil, "the response is not valid JSON: " + err.Error()
+	}
+	if err := resolved.Validate(value); err != nil {
+		return nil, err.Error()
+	}
+	normalized, err := json.Marshal(value)
+	if err != nil {
+		return nil, err.Error()
+	}
+	return normalized, ""
+}
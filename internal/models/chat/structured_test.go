@@ -0,0 +1,100 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// scriptedChat answers Chat calls with the given contents in order and
+// records the calls it received.
+type scriptedChat struct {
+	answers  []string
+	messages [][]Message
+	opts     []*ChatOptions
+}
+
+func (s *scriptedChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	s.messages = append(s.messages, messages)
+	s.opts = append(s.opts, opts)
+	content := s.answers[len(s.messages)-1]
+	return &types.ChatResponse{
+		Content: content,
+		Usage:   types.TokenUsage{PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15},
+	}, nil
+}
+
+func (s *scriptedChat) ChatStream(ctx context.Context, messages []Message, opts *ChatOptions) (<-chan types.StreamResponse, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *scriptedChat) GetModelName() string { return "scripted" }
+func (s *scriptedChat) GetModelID() string   { return "scripted" }
+
+var structuredTestSchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {"city": {"type": "string"}, "population": {"type": "integer"}},
+	"required": ["city", "population"]
+}`)
+
+func TestChatStructured_AcceptsValidAnswer(t *testing.T) {
+	model := &scriptedChat{answers: []string{"```json\n{\"city\": \"Paris\", \"population\": 2100000}\n```"}}
+
+	result, err := ChatStructured(context.Background(), model,
+		[]Message{{Role: "user", Content: "Largest city of France?"}},
+		&ChatOptions{Temperature: 0.2, Tools: []Tool{{Type: "function"}}}, structuredTestSchema, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Attempts)
+	assert.JSONEq(t, `{"city":"Paris","population":2100000}`, string(result.Value))
+
+	// The schema is passed as the response format, and tools are dropped.
+	require.Len(t, model.opts, 1)
+	assert.Equal(t, structuredTestSchema, model.opts[0].Format)
+	assert.Empty(t, model.opts[0].Tools)
+	assert.Equal(t, 0.2, model.opts[0].Temperature)
+}
+
+func TestChatStructured_RepairsInvalidAnswer(t *testing.T) {
+	model := &scriptedChat{answers: []string{
+		`{"city": "Paris"}`,
+		`{"city": "Paris", "population": 2100000}`,
+	}}
+
+	result, err := ChatStructured(context.Background(), model,
+		[]Message{{Role: "user", Content: "Largest city of France?"}}, nil, structuredTestSchema, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Attempts)
+	assert.Equal(t, 30, result.Response.Usage.TotalTokens)
+
+	// The repair call sees the invalid answer and the validation error.
+	repair := model.messages[1]
+	require.Len(t, repair, 3)
+	assert.Equal(t, "assistant", repair[1].Role)
+	assert.Equal(t, `{"city": "Paris"}`, repair[1].Content)
+	assert.True(t, strings.Contains(repair[2].Content, "population"), repair[2].Content)
+}
+
+func TestChatStructured_GivesUpAfterRepairs(t *testing.T) {
+	model := &scriptedChat{answers: []string{"not json", "still not json"}}
+
+	_, err := ChatStructured(context.Background(), model,
+		[]Message{{Role: "user", Content: "hi"}}, nil, structuredTestSchema, 1)
+	var outputErr *StructuredOutputError
+	require.True(t, errors.As(err, &outputErr), "got %v", err)
+	assert.Equal(t, 2, outputErr.Attempts)
+	assert.Equal(t, "still not json", outputErr.Content)
+}
+
+func TestChatStructured_RejectsInvalidSchema(t *testing.T) {
+	model := &scriptedChat{}
+	_, err := ChatStructured(context.Background(), model,
+		[]Message{{Role: "user", Content: "hi"}}, nil, json.RawMessage(`{"type": 3}`), 1)
+	require.Error(t, err)
+	assert.Empty(t, model.messages)
+}
@@ -0,0 +1,83 @@
+package chat
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Tencent/WeKnora/internal/tracing/telemetry"
+	"github.com/Tencent/WeKnora/internal/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingChat wraps a Chat and records every call as an OpenTelemetry span,
+// following the GenAI semantic conventions. Streams are spanned until their
+// last chunk. Like metricsChat it sits below the response cache.
+type tracingChat struct {
+	inner Chat
+}
+
+func (t *tracingChat) GetModelName() string { return t.inner.GetModelName() }
+func (t *tracingChat) GetModelID() string   { return t.inner.GetModelID() }
+func (t *tracingChat) Unwrap() Chat         { return t.inner }
+
+func (t *tracingChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	ctx, span := t.start(ctx, false)
+	resp, err := t.inner.Chat(ctx, messages, opts)
+	if resp != nil {
+		setUsageAttributes(span, &resp.Usage)
+		span.SetAttributes(attribute.StringSlice("gen_ai.response.finish_reasons", []string{resp.FinishReason}))
+	}
+	telemetry.End(span, err)
+	return resp, err
+}
+
+func (t *tracingChat) ChatStream(ctx context.Context, messages []Message, opts *ChatOptions) (<-chan types.StreamResponse, error) {
+	ctx, span := t.start(ctx, true)
+	ch, err := t.inner.ChatStream(ctx, messages, opts)
+	if err != nil || ch == nil {
+		telemetry.End(span, err)
+		return ch, err
+	}
+
+	wrapped := make(chan types.StreamResponse)
+	go func() {
+		defer close(wrapped)
+		var streamErr error
+		for resp := range ch {
+			if resp.ResponseType == types.ResponseTypeError {
+				streamErr = errors.New(resp.Content)
+			}
+			if resp.Usage != nil {
+				setUsageAttributes(span, resp.Usage)
+			}
+			wrapped <- resp
+		}
+		telemetry.End(span, streamErr)
+	}()
+	return wrapped, nil
+}
+
+func (t *tracingChat) start(ctx context.Context, stream bool) (context.Context, trace.Span) {
+	model := t.inner.GetModelName()
+	return telemetry.Start(ctx, "chat "+model,
+		attribute.String("gen_ai.operation.name", "chat"),
+		attribute.String("gen_ai.request.model", model),
+		attribute.Bool("weknora.stream", stream),
+	)
+}
+
+func setUsageAttributes(span trace.Span, usage *types.TokenUsage) {
+	span.SetAttributes(
+		attribute.Int("gen_ai.usage.input_tokens", usage.PromptTokens),
+		attribute.Int("gen_ai.usage.output_tokens", usage.CompletionTokens),
+	)
+}
+
+// wrapChatTracing wraps a Chat in spans when OpenTelemetry tracing is on.
+func wrapChatTracing(c Chat, err error) (Chat, error) {
+	if err != nil || c == nil || !telemetry.Enabled() {
+		return c, err
+	}
+	return &tracingChat{inner: c}, nil
+}
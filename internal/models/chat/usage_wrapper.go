@@ -0,0 +1,113 @@
+package chat
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/usage"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// usageChat wraps a Chat implementation, rejects calls once the tenant's
+// token budget is used up and reports the token usage of every call. Usage
+// reported by the provider is preferred; when a provider returns none, it is
+// estimated from the prompt and output lengths. It sits below the response
+// cache, so cache hits are not counted.
+type usageChat struct {
+	inner Chat
+}
+
+func (u *usageChat) GetModelName() string { return u.inner.GetModelName() }
+func (u *usageChat) GetModelID() string   { return u.inner.GetModelID() }
+func (u *usageChat) Unwrap() Chat         { return u.inner }
+
+func (u *usageChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	if err := usage.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+	resp, err := u.inner.Chat(ctx, messages, opts)
+	if resp != nil {
+		output := []string{resp.Content, resp.ReasoningContent}
+		for _, tc := range resp.ToolCalls {
+			output = append(output, tc.Function.Name, tc.Function.Arguments)
+		}
+		usage.Record(ctx, u.usageEvent(messages, &resp.Usage, output))
+	}
+	return resp, err
+}
+
+func (u *usageChat) ChatStream(ctx context.Context, messages []Message, opts *ChatOptions) (<-chan types.StreamResponse, error) {
+	if err := usage.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+	ch, err := u.inner.ChatStream(ctx, messages, opts)
+	if err != nil || ch == nil {
+		return ch, err
+	}
+
+	wrapped := make(chan types.StreamResponse)
+	go func() {
+		defer close(wrapped)
+		var output strings.Builder
+		var tokenUsage *types.TokenUsage
+		var toolCalls []types.LLMToolCall
+
+		for resp := range ch {
+			if resp.ResponseType == types.ResponseTypeAnswer || resp.ResponseType == types.ResponseTypeThinking {
+				output.WriteString(resp.Content)
+			}
+			if resp.Usage != nil {
+				tokenUsage = resp.Usage
+			}
+			if len(resp.ToolCalls) > 0 {
+				toolCalls = resp.ToolCalls
+			}
+			wrapped <- resp
+		}
+
+		texts := []string{output.String()}
+		for _, tc := range toolCalls {
+			texts = append(texts, tc.Function.Name, tc.Function.Arguments)
+		}
+		usage.Record(context.WithoutCancel(ctx), u.usageEvent(messages, tokenUsage, texts))
+	}()
+	return wrapped, nil
+}
+
+// usageEvent builds the usage event of a call from the provider's usage, or
+// from estimates when the provider reported none.
+func (u *usageChat) usageEvent(messages []Message, reported *types.TokenUsage, output []string) *types.TokenUsageEvent {
+	event := &types.TokenUsageEvent{
+		Kind:      types.TokenUsageKindChat,
+		ModelID:   u.inner.GetModelID(),
+		ModelName: u.inner.GetModelName(),
+	}
+	if reported != nil && reported.PromptTokens+reported.CompletionTokens > 0 {
+		event.PromptTokens = int64(reported.PromptTokens)
+		event.CompletionTokens = int64(reported.CompletionTokens)
+		return event
+	}
+
+	prompt := make([]string, 0, len(messages))
+	for _, m := range messages {
+		prompt = append(prompt, m.Content, m.ReasoningContent)
+		for _, part := range m.MultiContent {
+			prompt = append(prompt, part.Text)
+		}
+		for _, tc := range m.ToolCalls {
+			prompt = append(prompt, tc.Function.Name, tc.Function.Arguments)
+		}
+	}
+	event.PromptTokens = usage.EstimateTokens(prompt...)
+	event.CompletionTokens = usage.EstimateTokens(output...)
+	event.Estimated = true
+	return event
+}
+
+// wrapChatUsage wraps a Chat so its token usage is accounted.
+func wrapChatUsage(c Chat, err error) (Chat, error) {
+	if err != nil || c == nil {
+		return c, err
+	}
+	return &usageChat{inner: c}, nil
+}
@@ -0,0 +1,95 @@
+package chat
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/usage"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUsageRecorder struct {
+	budgetErr error
+	events    []*types.TokenUsageEvent
+}
+
+func (r *fakeUsageRecorder) CheckBudget(ctx context.Context) error { return r.budgetErr }
+
+func (r *fakeUsageRecorder) RecordUsage(ctx context.Context, event *types.TokenUsageEvent) {
+	r.events = append(r.events, event)
+}
+
+// fakeUsageChat answers with a fixed content and, when set, reports usage.
+type fakeUsageChat struct {
+	usage *types.TokenUsage
+}
+
+func (f *fakeUsageChat) Chat(ctx context.Context, messages []Message, opts *ChatOptions) (*types.ChatResponse, error) {
+	resp := &types.ChatResponse{Content: "twelve chars"}
+	if f.usage != nil {
+		resp.Usage = *f.usage
+	}
+	return resp, nil
+}
+
+func (f *fakeUsageChat) ChatStream(ctx context.Context, messages []Message, opts *ChatOptions) (<-chan types.StreamResponse, error) {
+	ch := make(chan types.StreamResponse, 3)
+	ch <- types.StreamResponse{ResponseType: types.ResponseTypeAnswer, Content: "twelve "}
+	ch <- types.StreamResponse{ResponseType: types.ResponseTypeAnswer, Content: "chars"}
+	if f.usage != nil {
+		ch <- types.StreamResponse{ResponseType: types.ResponseTypeAnswer, Done: true, Usage: f.usage}
+	}
+	close(ch)
+	return ch, nil
+}
+
+func (f *fakeUsageChat) GetModelName() string { return "fake-model" }
+func (f *fakeUsageChat) GetModelID() string   { return "fake-id" }
+
+func setUsageRecorder(t *testing.T, r usage.Recorder) {
+	t.Helper()
+	usage.SetRecorder(r)
+	t.Cleanup(func() { usage.SetRecorder(nil) })
+}
+
+func TestUsageChat_RecordsReportedAndEstimatedUsage(t *testing.T) {
+	recorder := &fakeUsageRecorder{}
+	setUsageRecorder(t, recorder)
+	messages := []Message{{Role: "user", Content: "sixteen chars!!!"}}
+
+	reported, _ := wrapChatUsage(&fakeUsageChat{usage: &types.TokenUsage{PromptTokens: 30, CompletionTokens: 7}}, nil)
+	_, err := reported.Chat(context.Background(), messages, nil)
+	require.NoError(t, err)
+
+	estimated, _ := wrapChatUsage(&fakeUsageChat{}, nil)
+	ch, err := estimated.ChatStream(context.Background(), messages, nil)
+	require.NoError(t, err)
+	for range ch {
+	}
+
+	// The usage of a stream is recorded before the stream closes.
+	require.Len(t, recorder.events, 2)
+	assert.Equal(t, &types.TokenUsageEvent{
+		Kind: types.TokenUsageKindChat, ModelID: "fake-id", ModelName: "fake-model",
+		PromptTokens: 30, CompletionTokens: 7,
+	}, recorder.events[0])
+	assert.Equal(t, &types.TokenUsageEvent{
+		Kind: types.TokenUsageKindChat, ModelID: "fake-id", ModelName: "fake-model",
+		PromptTokens: 5, CompletionTokens: 4, Estimated: true,
+	}, recorder.events[1])
+}
+
+func TestUsageChat_RejectsCallsOverBudget(t *testing.T) {
+	recorder := &fakeUsageRecorder{budgetErr: types.ErrTokenBudgetExceeded}
+	setUsageRecorder(t, recorder)
+
+	c, _ := wrapChatUsage(&fakeUsageChat{}, nil)
+	_, err := c.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+	assert.True(t, errors.Is(err, types.ErrTokenBudgetExceeded))
+	_, err = c.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+	assert.True(t, errors.Is(err, types.ErrTokenBudgetExceeded))
+	assert.Empty(t, recorder.events)
+}
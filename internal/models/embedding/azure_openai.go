@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/provider"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
 )
 
@@ -21,6 +22,7 @@ type AzureOpenAIEmbedder struct {
 	truncatePromptTokens      int
 	dimensions                int
 	modelID                   string
+	deployment                string
 	apiVersion                string
 	httpClient                *http.Client
 	maxRetries                int
@@ -34,6 +36,13 @@ func (e *AzureOpenAIEmbedder) SetCustomHeaders(headers map[string]string) {
 	e.customHeaders = headers
 }
 
+// SetDeployment 设置请求路由使用的部署名，未设置时沿用模型名。
+func (e *AzureOpenAIEmbedder) SetDeployment(deployment string) {
+	if deployment != "" {
+		e.deployment = deployment
+	}
+}
+
 func (e *AzureOpenAIEmbedder) SetSupportsDimensionOverride(supported bool) {
 	e.supportsDimensionOverride = supported
 }
@@ -57,7 +66,7 @@ func NewAzureOpenAIEmbedder(apiKey, baseURL, modelName string,
 		return nil, fmt.Errorf("deployment name (model name) is required")
 	}
 	if apiVersion == "" {
-		apiVersion = "2024-10-21"
+		apiVersion = provider.AzureOpenAIDefaultAPIVersion
 	}
 	if truncatePromptTokens == 0 {
 		truncatePromptTokens = 511
@@ -74,6 +83,7 @@ func NewAzureOpenAIEmbedder(apiKey, baseURL, modelName string,
 		truncatePromptTokens: truncatePromptTokens,
 		dimensions:           dimensions,
 		modelID:              modelID,
+		deployment:           modelName,
 		apiVersion:           apiVersion,
 		httpClient:           newEmbeddingHTTPClient(60 * time.Second),
 		maxRetries:           3,
@@ -100,6 +110,10 @@ func (e *AzureOpenAIEmbedder) BatchEmbed(ctx context.Context, texts []string) ([
 		Input:          texts,
 		EncodingFormat: "float",
 	}
+	if e.apiVersion == provider.AzureOpenAIV1APIVersion {
+		// v1 API 不按路径路由部署，由 model 字段指定
+		reqBody.Model = e.deployment
+	}
 	if e.supportsDimensionsParam() {
 		reqBody.Dimensions = e.dimensions
 	}
@@ -146,8 +160,7 @@ func (e *AzureOpenAIEmbedder) BatchEmbed(ctx context.Context, texts []string) ([
 }
 
 func (e *AzureOpenAIEmbedder) doRequestWithRetry(ctx context.Context, jsonData []byte) (*http.Response, error) {
-	url := fmt.Sprintf("%s/openai/deployments/%s/embeddings?api-version=%s",
-		e.baseURL, e.modelName, e.apiVersion)
+	url := provider.AzureOpenAIURL(e.baseURL, e.deployment, e.apiVersion, "embeddings")
 
 	var resp *http.Response
 	var err error
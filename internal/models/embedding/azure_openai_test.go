@@ -149,6 +149,55 @@ func TestAzureOpenAIEmbedderBatchEmbedSendsDimensionsWhenOverrideEnabledRegardle
 	}
 }
 
+func TestAzureOpenAIEmbedderV1APIRoutesDeploymentByModel(t *testing.T) {
+	t.Parallel()
+
+	var requestURL string
+	var requestBody map[string]any
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requestURL = r.URL.String()
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			t.Fatalf("decode request body: %v", err)
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(bytes.NewBufferString(`{"data":[{"embedding":[0.1,0.2],"index":0}]}`)),
+		}, nil
+	})
+
+	embedder, err := NewAzureOpenAIEmbedder(
+		"test-key",
+		"https://example-resource.openai.azure.com/openai/v1/",
+		"text-embedding-3-small",
+		511,
+		1536,
+		"text-embedding-3-small",
+		"v1",
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("create embedder: %v", err)
+	}
+	embedder.SetDeployment("embed-small-prod")
+	embedder.httpClient = &http.Client{Transport: transport}
+
+	if _, err := embedder.BatchEmbed(context.Background(), []string{"hello"}); err != nil {
+		t.Fatalf("BatchEmbed returned error: %v", err)
+	}
+
+	if want := "https://example-resource.openai.azure.com/openai/v1/embeddings"; requestURL != want {
+		t.Fatalf("unexpected request URL: got %s want %s", requestURL, want)
+	}
+	if got := requestBody["model"]; got != "embed-small-prod" {
+		t.Fatalf("unexpected model: got %v want embed-small-prod", got)
+	}
+	if got := embedder.GetModelName(); got != "text-embedding-3-small" {
+		t.Fatalf("unexpected model name: got %s", got)
+	}
+}
+
 type roundTripFunc func(*http.Request) (*http.Response, error)
 
 func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
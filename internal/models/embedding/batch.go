@@ -2,14 +2,78 @@ package embedding
 
 import (
 	"context"
+	"errors"
+	"net"
+	"net/http"
 	"os"
+	"regexp"
 	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
-	"github.com/Tencent/WeKnora/internal/models/utils"
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/panjf2000/ants/v2"
 )
 
+const (
+	// defaultEmbedBatchSize is the number of texts sent per request when
+	// BATCH_EMBED_SIZE is not set. Providers with a lower limit cap it
+	// through MaxBatchSize.
+	defaultEmbedBatchSize = 32
+	// embedBatchAttempts is how many times a batch is sent before it is
+	// given up on after transient failures.
+	embedBatchAttempts  = 3
+	embedBatchBaseDelay = 500 * time.Millisecond
+)
+
+// embedStatusPattern finds the HTTP status in embedder API errors
+// ("EmbedBatch API error: Http Status 503 Service Unavailable, ...").
+var embedStatusPattern = regexp.MustCompile(`Http Status (\d{3})`)
+
+// batchSizeLimiter is implemented by embedders whose API accepts at most
+// MaxBatchSize texts per request.
+type batchSizeLimiter interface {
+	MaxBatchSize() int
+}
+
+// BatchStats are the process-wide counters of BatchEmbedWithPool, used to
+// watch indexing throughput.
+type BatchStats struct {
+	Calls         int64   `json:"calls"`
+	Texts         int64   `json:"texts"`
+	Batches       int64   `json:"batches"`
+	FailedBatches int64   `json:"failed_batches"`
+	Retries       int64   `json:"retries"`
+	Splits        int64   `json:"splits"`
+	BusySeconds   float64 `json:"busy_seconds"`
+	TextsPerSec   float64 `json:"texts_per_second"`
+}
+
+var batchStats struct {
+	calls, texts, batches, failedBatches, retries, splits, busyNanos atomic.Int64
+}
+
+// GetBatchStats returns the batch embedding counters since the process
+// started. TextsPerSec is the number of texts embedded per second spent in
+// BatchEmbedWithPool calls.
+func GetBatchStats() BatchStats {
+	stats := BatchStats{
+		Calls:         batchStats.calls.Load(),
+		Texts:         batchStats.texts.Load(),
+		Batches:       batchStats.batches.Load(),
+		FailedBatches: batchStats.failedBatches.Load(),
+		Retries:       batchStats.retries.Load(),
+		Splits:        batchStats.splits.Load(),
+		BusySeconds:   time.Duration(batchStats.busyNanos.Load()).Seconds(),
+	}
+	if stats.BusySeconds > 0 {
+		stats.TextsPerSec = float64(stats.Texts) / stats.BusySeconds
+	}
+	return stats
+}
+
 type batchEmbedder struct {
 	pool *ants.Pool
 }
@@ -18,78 +82,165 @@ func NewBatchEmbedder(pool *ants.Pool) EmbedderPooler {
 	return &batchEmbedder{pool: pool}
 }
 
-type textEmbedding struct {
-	text    string
-	results []float32
-}
-
+// BatchEmbedWithPool splits texts into batches sized for the model and
+// embeds them concurrently on the shared goroutine pool, whose size
+// (CONCURRENCY_POOL_SIZE) bounds the embedding requests in flight. A batch
+// that fails transiently is retried; one the provider rejects is split in
+// halves, so a single bad text or a too large batch does not fail the rest.
 func (e *batchEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error) {
-	// Create goroutine pool for concurrent processing of document chunks
-	var wg sync.WaitGroup
-	var mu sync.Mutex  // For synchronizing access to error
-	var firstErr error // Record the first error that occurs
-	batchSizeStr := os.Getenv("BATCH_EMBED_SIZE")
-	if batchSizeStr == "" {
-		batchSizeStr = "5"
+	if len(texts) == 0 {
+		return nil, nil
 	}
-	batchSize, err := strconv.Atoi(batchSizeStr)
+	batchSize, err := embedBatchSize(model)
 	if err != nil {
 		return nil, err
 	}
-	textEmbeddings := utils.MapSlice(texts, func(text string) *textEmbedding {
-		return &textEmbedding{text: text}
-	})
+	start := time.Now()
 
-	// Function to process each document chunk
-	processChunk := func(texts []*textEmbedding) func() {
-		return func() {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		failed   atomic.Bool
+	)
+	results := make([][]float32, len(texts))
+	for offset := 0; offset < len(texts); offset += batchSize {
+		end := min(offset+batchSize, len(texts))
+		wg.Add(1)
+		err := e.pool.Submit(func() {
 			defer wg.Done()
-			// If an error has already occurred, don't continue processing
-			if firstErr != nil {
+			// Once a batch has failed the call fails; skip the rest.
+			if failed.Load() {
 				return
 			}
-			// Embed text
-			embedding, err := model.BatchEmbed(ctx, utils.MapSlice(texts, func(text *textEmbedding) string {
-				return text.text
-			}))
-			if err != nil {
+			if err := embedBatch(ctx, model, texts[offset:end], results[offset:end]); err != nil {
+				failed.Store(true)
 				mu.Lock()
 				if firstErr == nil {
 					firstErr = err
 				}
 				mu.Unlock()
-				return
 			}
-			mu.Lock()
-			for i, text := range texts {
-				if text == nil {
-					continue
-				}
-				text.results = embedding[i]
-			}
-			mu.Unlock()
-		}
-	}
-
-	// Submit all tasks to the goroutine pool
-	for _, texts := range utils.ChunkSlice(textEmbeddings, batchSize) {
-		wg.Add(1)
-		err := e.pool.Submit(processChunk(texts))
+		})
 		if err != nil {
+			wg.Done()
+			wg.Wait()
 			return nil, err
 		}
 	}
-
-	// Wait for all tasks to complete
 	wg.Wait()
 
-	// Check if any errors occurred
+	elapsed := time.Since(start)
+	batchStats.calls.Add(1)
+	batchStats.busyNanos.Add(int64(elapsed))
 	if firstErr != nil {
 		return nil, firstErr
 	}
-
-	results := utils.MapSlice(textEmbeddings, func(text *textEmbedding) []float32 {
-		return text.results
-	})
+	batchStats.texts.Add(int64(len(texts)))
+	logger.GetLogger(ctx).Infof("BatchEmbedWithPool: model=%s, texts=%d, batch_size=%d, elapsed=%s, %.1f texts/s",
+		model.GetModelName(), len(texts), batchSize, elapsed.Round(time.Millisecond),
+		float64(len(texts))/max(elapsed.Seconds(), 0.001))
 	return results, nil
 }
+
+// embedBatch embeds texts into out. Transient failures are retried with
+// backoff; when the provider rejects the batch, the halves are embedded
+// separately.
+func embedBatch(ctx context.Context, model Embedder, texts []string, out [][]float32) error {
+	batchStats.batches.Add(1)
+	delay := embedBatchBaseDelay
+	var err error
+	for attempt := 0; attempt < embedBatchAttempts; attempt++ {
+		if attempt > 0 {
+			batchStats.retries.Add(1)
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			delay *= 2
+		}
+		var embeddings [][]float32
+		embeddings, err = model.BatchEmbed(ctx, texts)
+		if err == nil && len(embeddings) != len(texts) {
+			err = errors.New("embedding count does not match input count")
+		}
+		if err == nil {
+			copy(out, embeddings)
+			return nil
+		}
+		if errors.Is(err, types.ErrTokenBudgetExceeded) {
+			batchStats.failedBatches.Add(1)
+			return err
+		}
+		if !isTransientEmbedError(ctx, err) {
+			break
+		}
+	}
+	// Smaller batches do not help a server that keeps failing.
+	if ctx.Err() != nil || len(texts) == 1 || isTransientEmbedError(ctx, err) {
+		batchStats.failedBatches.Add(1)
+		return err
+	}
+
+	batchStats.splits.Add(1)
+	logger.GetLogger(ctx).Warnf("BatchEmbedWithPool: batch of %d texts failed, retrying in halves: %v", len(texts), err)
+	mid := len(texts) / 2
+	if err := embedBatch(ctx, model, texts[:mid], out[:mid]); err != nil {
+		return err
+	}
+	return embedBatch(ctx, model, texts[mid:], out[mid:])
+}
+
+// embedBatchSize returns the batch size for model: BATCH_EMBED_SIZE, or
+// defaultEmbedBatchSize when unset, capped by the provider limit.
+func embedBatchSize(model Embedder) (int, error) {
+	size := defaultEmbedBatchSize
+	if v := os.Getenv("BATCH_EMBED_SIZE"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, err
+		}
+		size = n
+	}
+	if limit := maxBatchSize(model); limit > 0 && limit < size {
+		size = limit
+	}
+	return max(size, 1), nil
+}
+
+// maxBatchSize returns the provider limit of model, looking through the
+// debug and Langfuse wrappers; 0 means no limit.
+func maxBatchSize(model Embedder) int {
+	for model != nil {
+		if limiter, ok := model.(batchSizeLimiter); ok {
+			return limiter.MaxBatchSize()
+		}
+		w, ok := model.(interface{ Unwrap() Embedder })
+		if !ok {
+			return 0
+		}
+		model = w.Unwrap()
+	}
+	return 0
+}
+
+// isTransientEmbedError reports whether an embedding call failed for a
+// reason that may pass on its own: the server could not be reached, timed
+// out, was rate limited or failed with a server error. A cancelled ctx of
+// the caller is not.
+func isTransientEmbedError(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	var netErr net.Error
+	var opErr *net.OpError
+	if errors.As(err, &opErr) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return true
+	}
+	if m := embedStatusPattern.FindStringSubmatch(err.Error()); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+	}
+	return false
+}
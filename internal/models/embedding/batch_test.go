@@ -0,0 +1,190 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/panjf2000/ants/v2"
+)
+
+// fakeBatchEmbedder embeds each text as its length and records the batches
+// it was called with. fail decides the error of a call, if any.
+type fakeBatchEmbedder struct {
+	mu       sync.Mutex
+	batches  [][]string
+	maxBatch int
+	fail     func(call int, texts []string) error
+}
+
+func (f *fakeBatchEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	f.mu.Lock()
+	f.batches = append(f.batches, slices.Clone(texts))
+	call := len(f.batches)
+	f.mu.Unlock()
+	if f.fail != nil {
+		if err := f.fail(call, texts); err != nil {
+			return nil, err
+		}
+	}
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i] = []float32{float32(len(text))}
+	}
+	return out, nil
+}
+
+func (f *fakeBatchEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	out, err := f.BatchEmbed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+func (f *fakeBatchEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error) {
+	return nil, errors.New("not used")
+}
+func (f *fakeBatchEmbedder) GetModelName() string { return "fake" }
+func (f *fakeBatchEmbedder) GetDimensions() int   { return 1 }
+func (f *fakeBatchEmbedder) GetModelID() string   { return "fake" }
+func (f *fakeBatchEmbedder) MaxBatchSize() int    { return f.maxBatch }
+
+func newTestBatchEmbedder(t *testing.T) EmbedderPooler {
+	t.Helper()
+	pool, err := ants.NewPool(3)
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	t.Cleanup(pool.Release)
+	return NewBatchEmbedder(pool)
+}
+
+func testTexts(n int) []string {
+	texts := make([]string, n)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("%0*d", i+1, 0)
+	}
+	return texts
+}
+
+func checkEmbeddings(t *testing.T, texts []string, got [][]float32) {
+	t.Helper()
+	if len(got) != len(texts) {
+		t.Fatalf("got %d embeddings for %d texts", len(got), len(texts))
+	}
+	for i, text := range texts {
+		if len(got[i]) != 1 || got[i][0] != float32(len(text)) {
+			t.Fatalf("embedding %d = %v, want [%d]", i, got[i], len(text))
+		}
+	}
+}
+
+func TestBatchEmbedWithPoolUsesProviderBatchSize(t *testing.T) {
+	t.Setenv("BATCH_EMBED_SIZE", "")
+	model := &fakeBatchEmbedder{maxBatch: 10}
+	texts := testTexts(45)
+
+	got, err := newTestBatchEmbedder(t).BatchEmbedWithPool(context.Background(), &debugEmbedder{inner: model}, texts)
+	if err != nil {
+		t.Fatalf("BatchEmbedWithPool: %v", err)
+	}
+	checkEmbeddings(t, texts, got)
+	if len(model.batches) != 5 {
+		t.Fatalf("got %d batches, want 5 of at most 10 texts", len(model.batches))
+	}
+	for _, batch := range model.batches {
+		if len(batch) > 10 {
+			t.Fatalf("batch of %d texts exceeds the provider limit", len(batch))
+		}
+	}
+}
+
+func TestBatchEmbedWithPoolHonoursConfiguredBatchSize(t *testing.T) {
+	t.Setenv("BATCH_EMBED_SIZE", "4")
+	model := &fakeBatchEmbedder{}
+
+	if _, err := newTestBatchEmbedder(t).BatchEmbedWithPool(context.Background(), model, testTexts(10)); err != nil {
+		t.Fatalf("BatchEmbedWithPool: %v", err)
+	}
+	if len(model.batches) != 3 {
+		t.Fatalf("got %d batches, want 3", len(model.batches))
+	}
+}
+
+func TestBatchEmbedWithPoolRetriesTransientFailures(t *testing.T) {
+	t.Setenv("BATCH_EMBED_SIZE", "")
+	model := &fakeBatchEmbedder{fail: func(call int, texts []string) error {
+		if call == 1 {
+			return errors.New("EmbedBatch API error: Http Status 503 Service Unavailable, Response: busy")
+		}
+		return nil
+	}}
+	before := GetBatchStats()
+	texts := testTexts(5)
+
+	got, err := newTestBatchEmbedder(t).BatchEmbedWithPool(context.Background(), model, texts)
+	if err != nil {
+		t.Fatalf("BatchEmbedWithPool: %v", err)
+	}
+	checkEmbeddings(t, texts, got)
+	after := GetBatchStats()
+	if after.Retries-before.Retries != 1 {
+		t.Fatalf("got %d retries, want 1", after.Retries-before.Retries)
+	}
+	if after.Texts-before.Texts != 5 {
+		t.Fatalf("got %d texts counted, want 5", after.Texts-before.Texts)
+	}
+}
+
+func TestBatchEmbedWithPoolSplitsRejectedBatches(t *testing.T) {
+	t.Setenv("BATCH_EMBED_SIZE", "")
+	// The provider rejects any request holding more than 2 texts.
+	model := &fakeBatchEmbedder{fail: func(call int, texts []string) error {
+		if len(texts) > 2 {
+			return errors.New("EmbedBatch API error: Http Status 400 Bad Request, Response: batch too large")
+		}
+		return nil
+	}}
+	texts := testTexts(7)
+
+	got, err := newTestBatchEmbedder(t).BatchEmbedWithPool(context.Background(), model, texts)
+	if err != nil {
+		t.Fatalf("BatchEmbedWithPool: %v", err)
+	}
+	checkEmbeddings(t, texts, got)
+}
+
+func TestBatchEmbedWithPoolFailsOnRejectedText(t *testing.T) {
+	t.Setenv("BATCH_EMBED_SIZE", "")
+	texts := testTexts(6)
+	model := &fakeBatchEmbedder{fail: func(call int, batch []string) error {
+		if slices.Contains(batch, texts[3]) {
+			return errors.New("EmbedBatch API error: Http Status 400 Bad Request, Response: invalid input")
+		}
+		return nil
+	}}
+
+	if _, err := newTestBatchEmbedder(t).BatchEmbedWithPool(context.Background(), model, texts); err == nil {
+		t.Fatal("expected the rejected text to fail the call")
+	}
+}
+
+func TestBatchEmbedWithPoolStopsOnTokenBudget(t *testing.T) {
+	t.Setenv("BATCH_EMBED_SIZE", "")
+	model := &fakeBatchEmbedder{fail: func(call int, texts []string) error {
+		return fmt.Errorf("%w: tenant 1", types.ErrTokenBudgetExceeded)
+	}}
+
+	_, err := newTestBatchEmbedder(t).BatchEmbedWithPool(context.Background(), model, testTexts(8))
+	if !errors.Is(err, types.ErrTokenBudgetExceeded) {
+		t.Fatalf("got %v, want the budget error", err)
+	}
+	if len(model.batches) != 1 {
+		t.Fatalf("got %d calls, want the batch neither retried nor split", len(model.batches))
+	}
+}
@@ -0,0 +1,234 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/models/utils"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// BedrockEmbedder implements text vectorization using the Amazon Bedrock
+// InvokeModel API. Amazon Titan models embed one text per request; Cohere
+// models take a batch.
+type BedrockEmbedder struct {
+	baseURL                   string
+	modelName                 string
+	dimensions                int
+	modelID                   string
+	auth                      utils.AWSAuth
+	httpClient                *http.Client
+	maxRetries                int
+	customHeaders             map[string]string
+	supportsDimensionOverride bool
+	EmbedderPooler
+}
+
+type bedrockTitanEmbedRequest struct {
+	InputText  string `json:"inputText"`
+	Dimensions int    `json:"dimensions,omitempty"`
+	Normalize  bool   `json:"normalize,omitempty"`
+}
+
+type bedrockTitanEmbedResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
+
+type bedrockCohereEmbedRequest struct {
+	Texts           []string `json:"texts"`
+	InputType       string   `json:"input_type"`
+	Truncate        string   `json:"truncate,omitempty"`
+	EmbeddingTypes  []string `json:"embedding_types,omitempty"`
+	OutputDimension int      `json:"output_dimension,omitempty"`
+}
+
+type bedrockCohereEmbedResponse struct {
+	Embeddings struct {
+		Float [][]float32 `json:"float"`
+	} `json:"embeddings"`
+}
+
+// NewBedrockEmbedder creates a new Amazon Bedrock embedder
+func NewBedrockEmbedder(config Config, pooler EmbedderPooler) (*BedrockEmbedder, error) {
+	if config.ModelName == "" {
+		return nil, fmt.Errorf("model ID (model name) is required")
+	}
+	if strings.TrimSpace(config.APIKey) == "" {
+		return nil, fmt.Errorf("API key or secret access key is required")
+	}
+
+	region := provider.BedrockRegion(config.BaseURL, config.ExtraConfig)
+	baseURL := provider.BedrockRuntimeURL(config.BaseURL, region)
+	if baseURL != provider.BedrockRuntimeURL("", region) {
+		if err := validateEmbeddingBaseURL(baseURL); err != nil {
+			return nil, err
+		}
+	}
+
+	return &BedrockEmbedder{
+		baseURL:        baseURL,
+		modelName:      config.ModelName,
+		dimensions:     config.Dimensions,
+		modelID:        config.ModelID,
+		auth:           provider.BedrockAuth(config.APIKey, region, config.ExtraConfig),
+		httpClient:     newEmbeddingHTTPClient(60 * time.Second),
+		maxRetries:     3,
+		EmbedderPooler: pooler,
+	}, nil
+}
+
+// SetCustomHeaders 设置用户自定义 HTTP 请求头（类似 OpenAI Python SDK 的 extra_headers）。
+func (e *BedrockEmbedder) SetCustomHeaders(headers map[string]string) {
+	e.customHeaders = headers
+}
+
+func (e *BedrockEmbedder) SetSupportsDimensionOverride(supported bool) {
+	e.supportsDimensionOverride = supported
+}
+
+func (e *BedrockEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.BatchEmbed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("no embedding returned")
+	}
+	return embeddings[0], nil
+}
+
+func (e *BedrockEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	logger.GetLogger(ctx).Debugf("BedrockEmbedder BatchEmbed: model=%s, input_count=%d",
+		e.modelName, len(texts))
+
+	if e.isCohere() {
+		return e.embedCohere(ctx, texts)
+	}
+	embeddings := make([][]float32, 0, len(texts))
+	for _, text := range texts {
+		reqBody := bedrockTitanEmbedRequest{InputText: text}
+		if e.supportsDimensionOverride && e.dimensions > 0 {
+			reqBody.Dimensions = e.dimensions
+			reqBody.Normalize = true
+		}
+		var response bedrockTitanEmbedResponse
+		if err := e.invoke(ctx, reqBody, &response); err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, response.Embedding)
+	}
+	return embeddings, nil
+}
+
+func (e *BedrockEmbedder) embedCohere(ctx context.Context, texts []string) ([][]float32, error) {
+	reqBody := bedrockCohereEmbedRequest{
+		Texts:          texts,
+		InputType:      "search_document",
+		Truncate:       "END",
+		EmbeddingTypes: []string{"float"},
+	}
+	if e.supportsDimensionOverride && e.dimensions > 0 {
+		reqBody.OutputDimension = e.dimensions
+	}
+	var response bedrockCohereEmbedResponse
+	if err := e.invoke(ctx, reqBody, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Embeddings.Float) != len(texts) {
+		return nil, fmt.Errorf("Bedrock BatchEmbed returned %d embeddings for %d inputs",
+			len(response.Embeddings.Float), len(texts))
+	}
+	return response.Embeddings.Float, nil
+}
+
+// isCohere reports whether the model is a Cohere embedding model, including
+// cross-region inference profiles such as us.cohere.embed-v4:0.
+func (e *BedrockEmbedder) isCohere() bool {
+	return strings.Contains(e.modelName, "cohere.embed")
+}
+
+// invoke calls InvokeModel with reqBody and decodes the response into out.
+func (e *BedrockEmbedder) invoke(ctx context.Context, reqBody, out any) error {
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	resp, err := e.doRequestWithRetry(ctx, jsonData)
+	if err != nil {
+		return fmt.Errorf("send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		bodyStr := string(body)
+		if len(bodyStr) > 1000 {
+			bodyStr = bodyStr[:1000] + "... (truncated)"
+		}
+		return fmt.Errorf("Bedrock Embedding API error: Http Status %s, Response: %s", resp.Status, bodyStr)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
+}
+
+func (e *BedrockEmbedder) doRequestWithRetry(ctx context.Context, jsonData []byte) (*http.Response, error) {
+	endpoint := fmt.Sprintf("%s/model/%s/invoke", e.baseURL, url.PathEscape(e.modelName))
+
+	var resp *http.Response
+	var err error
+
+	for i := 0; i <= e.maxRetries; i++ {
+		if i > 0 {
+			backoffTime := time.Duration(1<<uint(i-1)) * time.Second
+			if backoffTime > 10*time.Second {
+				backoffTime = 10 * time.Second
+			}
+			select {
+			case <-time.After(backoffTime):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+
+		req, reqErr := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(jsonData))
+		if reqErr != nil {
+			err = reqErr
+			continue
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		secutils.ApplyCustomHeaders(req, e.customHeaders)
+		// Sign each attempt afresh: the SigV4 signature covers the request time.
+		if err = e.auth.Apply(ctx, req, jsonData); err != nil {
+			return nil, err
+		}
+
+		resp, err = e.httpClient.Do(req)
+		if err == nil {
+			return resp, nil
+		}
+	}
+	return nil, err
+}
+
+func (e *BedrockEmbedder) GetModelName() string { return e.modelName }
+func (e *BedrockEmbedder) GetDimensions() int   { return e.dimensions }
+func (e *BedrockEmbedder) GetModelID() string   { return e.modelID }
@@ -0,0 +1,108 @@
+package embedding
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBedrockEmbedderTitanEmbedsOneTextPerRequest(t *testing.T) {
+	t.Parallel()
+
+	var paths []string
+	var inputs []string
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		paths = append(paths, r.URL.Path)
+		if auth := r.Header.Get("Authorization"); !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") ||
+			!strings.Contains(auth, "/eu-west-1/bedrock/aws4_request") {
+			t.Fatalf("unexpected Authorization header: %s", auth)
+		}
+		var body bedrockTitanEmbedRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request body: %v", err)
+		}
+		if body.Dimensions != 256 || !body.Normalize {
+			t.Fatalf("expected dimensions 256 with normalize, got %+v", body)
+		}
+		inputs = append(inputs, body.InputText)
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(bytes.NewBufferString(`{"embedding":[0.1,0.2],"inputTextTokenCount":1}`)),
+		}, nil
+	})
+
+	embedder, err := NewBedrockEmbedder(Config{
+		APIKey:      "secret",
+		ModelName:   "amazon.titan-embed-text-v2:0",
+		Dimensions:  256,
+		ExtraConfig: map[string]string{"region": "eu-west-1", "access_key_id": "AKIDEXAMPLE"},
+	}, nil)
+	if err != nil {
+		t.Fatalf("create embedder: %v", err)
+	}
+	embedder.SetSupportsDimensionOverride(true)
+	embedder.httpClient = &http.Client{Transport: transport}
+
+	embeddings, err := embedder.BatchEmbed(context.Background(), []string{"hello", "world"})
+	if err != nil {
+		t.Fatalf("BatchEmbed returned error: %v", err)
+	}
+	if len(embeddings) != 2 {
+		t.Fatalf("expected 2 embeddings, got %d", len(embeddings))
+	}
+	if strings.Join(inputs, ",") != "hello,world" {
+		t.Fatalf("unexpected inputs: %v", inputs)
+	}
+	if want := "/model/amazon.titan-embed-text-v2:0/invoke"; paths[0] != want {
+		t.Fatalf("unexpected request path: got %s want %s", paths[0], want)
+	}
+}
+
+func TestBedrockEmbedderCohereEmbedsBatch(t *testing.T) {
+	t.Parallel()
+
+	var requestBody bedrockCohereEmbedRequest
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if got := r.URL.Host; got != "bedrock-runtime.us-east-1.amazonaws.com" {
+			t.Fatalf("unexpected host: %s", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer bedrock-api-key" {
+			t.Fatalf("unexpected Authorization header: %s", got)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			t.Fatalf("decode request body: %v", err)
+		}
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(bytes.NewBufferString(`{"embeddings":{"float":[[0.1,0.2],[0.3,0.4]]}}`)),
+		}, nil
+	})
+
+	embedder, err := NewBedrockEmbedder(Config{
+		APIKey:    "bedrock-api-key",
+		ModelName: "us.cohere.embed-v4:0",
+	}, nil)
+	if err != nil {
+		t.Fatalf("create embedder: %v", err)
+	}
+	embedder.httpClient = &http.Client{Transport: transport}
+
+	embeddings, err := embedder.BatchEmbed(context.Background(), []string{"hello", "world"})
+	if err != nil {
+		t.Fatalf("BatchEmbed returned error: %v", err)
+	}
+	if len(embeddings) != 2 || embeddings[1][1] != 0.4 {
+		t.Fatalf("unexpected embeddings: %v", embeddings)
+	}
+	if len(requestBody.Texts) != 2 || requestBody.InputType != "search_document" {
+		t.Fatalf("unexpected request body: %+v", requestBody)
+	}
+}
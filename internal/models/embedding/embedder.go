@@ -9,6 +9,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/models/provider"
 	"github.com/Tencent/WeKnora/internal/models/utils/ollama"
 	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/tracing/telemetry"
 	"github.com/Tencent/WeKnora/internal/types"
 )
 
@@ -36,6 +37,10 @@ type EmbedderPooler interface {
 	BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error)
 }
 
+// aliyunTextEmbedMaxBatchSize is the most texts the DashScope text embedding
+// models (text-embedding-v3/v4) accept per request.
+const aliyunTextEmbedMaxBatchSize = 10
+
 // EmbedderType represents the embedder type
 type EmbedderType string
 
@@ -90,6 +95,11 @@ func NewEmbedder(config Config, pooler EmbedderPooler, ollamaService *ollama.Oll
 	if setter, ok := e.(interface{ SetSupportsDimensionOverride(bool) }); ok {
 		setter.SetSupportsDimensionOverride(config.SupportsDimensionOverride)
 	}
+	e = &usageEmbedder{inner: e}
+	e = &metricsEmbedder{inner: e}
+	if telemetry.Enabled() {
+		e = &tracingEmbedder{inner: e}
+	}
 	if logger.LLMDebugEnabled() {
 		e = &debugEmbedder{inner: e}
 	}
@@ -159,6 +169,8 @@ func newEmbedder(config Config, pooler EmbedderPooler, ollamaService *ollama.Oll
 					pooler)
 				if openaiEmb != nil {
 					openaiEmb.SetCustomHeaders(config.CustomHeaders)
+					// DashScope 文本向量模型单次请求最多 10 条输入
+					openaiEmb.SetMaxBatchSize(aliyunTextEmbedMaxBatchSize)
 				}
 				embedder, err = openaiEmb, oErr
 			}
@@ -192,25 +204,27 @@ func newEmbedder(config Config, pooler EmbedderPooler, ollamaService *ollama.Oll
 			embedder, err = jinaEmb, jErr
 			return embedder, err
 		case provider.ProviderAzureOpenAI:
-			apiVersion := "2024-10-21"
-			if config.ExtraConfig != nil {
-				if v, ok := config.ExtraConfig["api_version"]; ok {
-					apiVersion = v
-				}
-			}
 			azureEmb, azErr := NewAzureOpenAIEmbedder(config.APIKey,
 				config.BaseURL,
 				config.ModelName,
 				config.TruncatePromptTokens,
 				config.Dimensions,
 				config.ModelID,
-				apiVersion,
+				provider.AzureOpenAIAPIVersion(config.ExtraConfig),
 				pooler)
 			if azureEmb != nil {
 				azureEmb.SetCustomHeaders(config.CustomHeaders)
+				azureEmb.SetDeployment(provider.AzureOpenAIDeployment(config.ModelName, config.ExtraConfig))
 			}
 			embedder, err = azureEmb, azErr
 			return embedder, err
+		case provider.ProviderBedrock:
+			bedrockEmb, bErr := NewBedrockEmbedder(config, pooler)
+			if bedrockEmb != nil {
+				bedrockEmb.SetCustomHeaders(config.CustomHeaders)
+			}
+			embedder, err = bedrockEmb, bErr
+			return embedder, err
 		case provider.ProviderNvidia:
 			nvEmb, nErr := NewNvidiaEmbedder(config.APIKey,
 				config.BaseURL,
@@ -252,6 +266,10 @@ func newEmbedder(config Config, pooler EmbedderPooler, ollamaService *ollama.Oll
 		case provider.ProviderWeKnoraCloud:
 			embedder, err = NewWeKnoraCloudEmbedder(config)
 			return embedder, err
+		case provider.ProviderSelfHosted:
+			selfHostedEmb, sErr := NewSelfHostedEmbedder(config, pooler)
+			embedder, err = selfHostedEmb, sErr
+			return embedder, err
 		default:
 			// Use OpenAI-compatible embedder for other providers
 			openaiEmb, oErr := NewOpenAIEmbedder(config.APIKey,
@@ -81,6 +81,7 @@ func (l *langfuseEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedde
 func (l *langfuseEmbedder) GetModelName() string { return l.inner.GetModelName() }
 func (l *langfuseEmbedder) GetDimensions() int   { return l.inner.GetDimensions() }
 func (l *langfuseEmbedder) GetModelID() string   { return l.inner.GetModelID() }
+func (l *langfuseEmbedder) Unwrap() Embedder     { return l.inner }
 
 // approxEmbeddingUsage estimates input tokens as ~rune_count / 4, matching the
 // rule of thumb OpenAI uses in their tokenizer docs. This is purely for cost
@@ -35,6 +35,7 @@ func (d *debugEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder,
 func (d *debugEmbedder) GetModelName() string { return d.inner.GetModelName() }
 func (d *debugEmbedder) GetDimensions() int   { return d.inner.GetDimensions() }
 func (d *debugEmbedder) GetModelID() string   { return d.inner.GetModelID() }
+func (d *debugEmbedder) Unwrap() Embedder     { return d.inner }
 
 func singleToDouble(v []float32) [][]float32 {
 	if v == nil {
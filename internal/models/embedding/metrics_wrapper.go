@@ -0,0 +1,39 @@
+package embedding
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// metricsEmbedder wraps an Embedder and reports the latency of every call to
+// Prometheus.
+type metricsEmbedder struct {
+	inner Embedder
+}
+
+func (m *metricsEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	start := time.Now()
+	result, err := m.inner.Embed(ctx, text)
+	metrics.ObserveModelCall(ctx, types.TokenUsageKindEmbedding, m.inner.GetModelName(), start, err)
+	return result, err
+}
+
+func (m *metricsEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	start := time.Now()
+	result, err := m.inner.BatchEmbed(ctx, texts)
+	metrics.ObserveModelCall(ctx, types.TokenUsageKindEmbedding, m.inner.GetModelName(), start, err)
+	return result, err
+}
+
+func (m *metricsEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error) {
+	// model is the outermost wrapper; its BatchEmbed reaches this one.
+	return m.inner.BatchEmbedWithPool(ctx, model, texts)
+}
+
+func (m *metricsEmbedder) GetModelName() string { return m.inner.GetModelName() }
+func (m *metricsEmbedder) GetDimensions() int   { return m.inner.GetDimensions() }
+func (m *metricsEmbedder) GetModelID() string   { return m.inner.GetModelID() }
+func (m *metricsEmbedder) Unwrap() Embedder     { return m.inner }
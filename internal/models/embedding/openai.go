@@ -26,6 +26,8 @@ type OpenAIEmbedder struct {
 	maxRetries                int
 	customHeaders             map[string]string
 	supportsDimensionOverride bool
+	// maxBatchSize is the most texts the API accepts per request; 0 means no limit.
+	maxBatchSize int
 	EmbedderPooler
 }
 
@@ -92,6 +94,16 @@ func (e *OpenAIEmbedder) SetSupportsDimensionOverride(supported bool) {
 	e.supportsDimensionOverride = supported
 }
 
+// SetMaxBatchSize sets the most texts the API accepts per request.
+func (e *OpenAIEmbedder) SetMaxBatchSize(size int) {
+	e.maxBatchSize = size
+}
+
+// MaxBatchSize returns the most texts the API accepts per request; 0 means no limit.
+func (e *OpenAIEmbedder) MaxBatchSize() int {
+	return e.maxBatchSize
+}
+
 // Embed converts text to vector
 func (e *OpenAIEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
 	for range 3 {
@@ -0,0 +1,94 @@
+package embedding
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/models/utils"
+)
+
+// SelfHostedEmbedder spreads embedding requests over the replicas of a
+// self-hosted OpenAI-compatible server (vLLM, llama.cpp server): each batch
+// goes to the healthy replica with the fewest requests in flight, and moves
+// on to the next replica when one is unavailable.
+type SelfHostedEmbedder struct {
+	pool     *utils.EndpointPool
+	replicas []*OpenAIEmbedder
+	EmbedderPooler
+}
+
+// NewSelfHostedEmbedder creates a new self-hosted embedder
+func NewSelfHostedEmbedder(config Config, pooler EmbedderPooler) (*SelfHostedEmbedder, error) {
+	endpoints := provider.SelfHostedEndpoints(config.BaseURL, config.ExtraConfig)
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("base URL is required for self-hosted provider")
+	}
+
+	replicas := make([]*OpenAIEmbedder, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		replica, err := NewOpenAIEmbedder(config.APIKey,
+			endpoint,
+			config.ModelName,
+			config.TruncatePromptTokens,
+			config.Dimensions,
+			config.ModelID,
+			pooler)
+		if err != nil {
+			return nil, fmt.Errorf("replica %s: %w", endpoint, err)
+		}
+		replica.SetCustomHeaders(config.CustomHeaders)
+		replicas = append(replicas, replica)
+	}
+
+	return &SelfHostedEmbedder{
+		pool:           utils.GetEndpointPool(endpoints, provider.SelfHostedHealthCheckPath(config.ExtraConfig)),
+		replicas:       replicas,
+		EmbedderPooler: pooler,
+	}, nil
+}
+
+func (e *SelfHostedEmbedder) SetSupportsDimensionOverride(supported bool) {
+	for _, replica := range e.replicas {
+		replica.SetSupportsDimensionOverride(supported)
+	}
+}
+
+func (e *SelfHostedEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := e.BatchEmbed(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	if len(embeddings) == 0 {
+		return nil, fmt.Errorf("no embedding returned")
+	}
+	return embeddings[0], nil
+}
+
+func (e *SelfHostedEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	var (
+		tried   []int
+		lastErr error
+	)
+	for {
+		i, release, ok := e.pool.Acquire(tried)
+		if !ok {
+			return nil, lastErr
+		}
+		embeddings, err := e.replicas[i].BatchEmbed(ctx, texts)
+		down := isTransientEmbedError(ctx, err)
+		release(down)
+		if !down {
+			return embeddings, err
+		}
+		logger.GetLogger(ctx).Warnf("SelfHostedEmbedder replica %s unavailable, trying next: %v",
+			e.pool.BaseURL(i), err)
+		tried = append(tried, i)
+		lastErr = err
+	}
+}
+
+func (e *SelfHostedEmbedder) GetModelName() string { return e.replicas[0].GetModelName() }
+func (e *SelfHostedEmbedder) GetDimensions() int   { return e.replicas[0].GetDimensions() }
+func (e *SelfHostedEmbedder) GetModelID() string   { return e.replicas[0].GetModelID() }
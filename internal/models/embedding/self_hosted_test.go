@@ -0,0 +1,54 @@
+package embedding
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+func TestSelfHostedEmbedderFailsOverToHealthyReplica(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+
+	newReplica := func(status int) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/v1/embeddings" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			w.WriteHeader(status)
+			if status == http.StatusOK {
+				_, _ = w.Write([]byte(`{"data":[{"embedding":[0.1,0.2],"index":0}]}`))
+			}
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	down := newReplica(http.StatusServiceUnavailable)
+	up := newReplica(http.StatusOK)
+
+	embedder, err := NewEmbedder(Config{
+		Source:      types.ModelSourceRemote,
+		BaseURL:     down.URL + "/v1",
+		ModelName:   "bge-m3",
+		Dimensions:  2,
+		Provider:    string(provider.ProviderSelfHosted),
+		ExtraConfig: map[string]string{"endpoints": up.URL + "/v1"},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("create embedder: %v", err)
+	}
+
+	for range 3 {
+		embedding, err := embedder.Embed(context.Background(), "hello")
+		if err != nil {
+			t.Fatalf("embed: %v", err)
+		}
+		if len(embedding) != 2 {
+			t.Fatalf("unexpected embedding: %v", embedding)
+		}
+	}
+}
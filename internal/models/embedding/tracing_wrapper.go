@@ -0,0 +1,48 @@
+package embedding
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/tracing/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingEmbedder wraps an Embedder and records every call as an
+// OpenTelemetry span, following the GenAI semantic conventions.
+type tracingEmbedder struct {
+	inner Embedder
+}
+
+func (t *tracingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	ctx, span := t.start(ctx, 1)
+	result, err := t.inner.Embed(ctx, text)
+	telemetry.End(span, err)
+	return result, err
+}
+
+func (t *tracingEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	ctx, span := t.start(ctx, len(texts))
+	result, err := t.inner.BatchEmbed(ctx, texts)
+	telemetry.End(span, err)
+	return result, err
+}
+
+func (t *tracingEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error) {
+	// model is the outermost wrapper; its BatchEmbed reaches this one.
+	return t.inner.BatchEmbedWithPool(ctx, model, texts)
+}
+
+func (t *tracingEmbedder) GetModelName() string { return t.inner.GetModelName() }
+func (t *tracingEmbedder) GetDimensions() int   { return t.inner.GetDimensions() }
+func (t *tracingEmbedder) GetModelID() string   { return t.inner.GetModelID() }
+func (t *tracingEmbedder) Unwrap() Embedder     { return t.inner }
+
+func (t *tracingEmbedder) start(ctx context.Context, inputs int) (context.Context, trace.Span) {
+	model := t.inner.GetModelName()
+	return telemetry.Start(ctx, "embeddings "+model,
+		attribute.String("gen_ai.operation.name", "embeddings"),
+		attribute.String("gen_ai.request.model", model),
+		attribute.Int("weknora.embedding.inputs", inputs),
+	)
+}
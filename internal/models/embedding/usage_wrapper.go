@@ -0,0 +1,61 @@
+package embedding
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/models/usage"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// usageEmbedder wraps an Embedder, rejects calls once the tenant's token
+// budget is used up and reports the input tokens of every successful call.
+// Embedding APIs rarely return usage, so the tokens are estimated from the
+// text lengths.
+type usageEmbedder struct {
+	inner Embedder
+}
+
+func (u *usageEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if err := usage.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+	result, err := u.inner.Embed(ctx, text)
+	if err == nil {
+		u.record(ctx, text)
+	}
+	return result, err
+}
+
+func (u *usageEmbedder) BatchEmbed(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := usage.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+	result, err := u.inner.BatchEmbed(ctx, texts)
+	if err == nil {
+		u.record(ctx, texts...)
+	}
+	return result, err
+}
+
+func (u *usageEmbedder) BatchEmbedWithPool(ctx context.Context, model Embedder, texts []string) ([][]float32, error) {
+	if err := usage.CheckBudget(ctx); err != nil {
+		return nil, err
+	}
+	// model is the outermost wrapper; its BatchEmbed reaches this one.
+	return u.inner.BatchEmbedWithPool(ctx, model, texts)
+}
+
+func (u *usageEmbedder) record(ctx context.Context, texts ...string) {
+	usage.Record(ctx, &types.TokenUsageEvent{
+		Kind:            types.TokenUsageKindEmbedding,
+		ModelID:         u.inner.GetModelID(),
+		ModelName:       u.inner.GetModelName(),
+		EmbeddingTokens: usage.EstimateTokens(texts...),
+		Estimated:       true,
+	})
+}
+
+func (u *usageEmbedder) GetModelName() string { return u.inner.GetModelName() }
+func (u *usageEmbedder) GetDimensions() int   { return u.inner.GetDimensions() }
+func (u *usageEmbedder) GetModelID() string   { return u.inner.GetModelID() }
+func (u *usageEmbedder) Unwrap() Embedder     { return u.inner }
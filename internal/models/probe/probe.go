@@ -0,0 +1,359 @@
+// Package probe 对尚未保存的模型配置做实际调用，检查连通性并探测模型能力，
+// 供模型管理界面在保存前展示。
+package probe
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// DefaultCheckTimeout 是单项检查的默认超时
+	DefaultCheckTimeout = 30 * time.Second
+	// DefaultMaxContextTokens 是上下文长度实测的默认上限
+	DefaultMaxContextTokens = 32768
+	// MaxContextTokensLimit 是上下文长度实测允许的最大上限
+	MaxContextTokensLimit = 131072
+	// minContextProbeTokens 是上下文长度实测的起始长度，之后逐次翻倍
+	minContextProbeTokens = 4096
+)
+
+// Status 是单项检查的结果
+type Status string
+
+const (
+	StatusPassed      Status = "passed"      // 检查通过
+	StatusFailed      Status = "failed"      // 调用失败
+	StatusUnsupported Status = "unsupported" // 调用成功，但模型不具备该能力
+	StatusSkipped     Status = "skipped"     // 未执行
+)
+
+// ContextSource 表示上下文长度的来源
+type ContextSource string
+
+const (
+	ContextSourceReported ContextSource = "reported" // 推理服务上报（vLLM max_model_len、llama.cpp n_ctx）
+	ContextSourceProbed   ContextSource = "probed"   // 发送长提示词实测得到的下限
+)
+
+// Check 是一项检查的结果
+type Check struct {
+	Status    Status `json:"status"`
+	LatencyMs int64  `json:"latency_ms"`
+	Message   string `json:"message,omitempty"`
+}
+
+// StreamCheck 是流式输出检查的结果
+type StreamCheck struct {
+	Check
+	FirstTokenMs int64 `json:"first_token_ms,omitempty"` // 收到首个内容分片的耗时
+	Chunks       int   `json:"chunks"`                   // 收到的内容分片数
+}
+
+// ContextCheck 是上下文长度检查的结果
+type ContextCheck struct {
+	Check
+	Tokens int           `json:"tokens"`           // 上报的上下文长度，或实测通过的最大长度
+	Source ContextSource `json:"source,omitempty"` // Tokens 的来源
+}
+
+// EmbeddingCheck 是 Embedding 检查的结果
+type EmbeddingCheck struct {
+	Check
+	Dimension           int `json:"dimension"`                      // 实际返回的向量维度
+	ConfiguredDimension int `json:"configured_dimension,omitempty"` // 配置中填写的维度
+}
+
+// Report 是一次探测的完整报告，只包含与模型类型相关的检查
+type Report struct {
+	ModelName  string          `json:"model_name"`
+	ModelType  types.ModelType `json:"model_type"`
+	Available  bool            `json:"available"`
+	DurationMs int64           `json:"duration_ms"`
+	Chat       *Check          `json:"chat,omitempty"`
+	Streaming  *StreamCheck    `json:"streaming,omitempty"`
+	ToolCalls  *Check          `json:"tool_calls,omitempty"`
+	Context    *ContextCheck   `json:"context,omitempty"`
+	Embedding  *EmbeddingCheck `json:"embedding,omitempty"`
+}
+
+// Options 控制探测范围
+type Options struct {
+	// ProbeContext 为 true 时，推理服务未上报上下文长度的模型会用逐次翻倍的长提示词实测，
+	// 会消耗较多 token，默认关闭
+	ProbeContext bool
+	// MaxContextTokens 是实测的上限，默认 DefaultMaxContextTokens，最大 MaxContextTokensLimit
+	MaxContextTokens int
+	// CheckTimeout 是单项检查的超时，默认 DefaultCheckTimeout
+	CheckTimeout time.Duration
+}
+
+func (o Options) checkTimeout() time.Duration {
+	if o.CheckTimeout <= 0 {
+		return DefaultCheckTimeout
+	}
+	return o.CheckTimeout
+}
+
+func (o Options) maxContextTokens() int {
+	if o.MaxContextTokens <= 0 {
+		return DefaultMaxContextTokens
+	}
+	return min(o.MaxContextTokens, MaxContextTokensLimit)
+}
+
+// probeTool 是工具调用检查使用的工具
+var probeTool = chat.Tool{
+	Type: "function",
+	Function: chat.FunctionDef{
+		Name:        "get_weather",
+		Description: "Get the current weather of a city",
+		Parameters:  []byte(`{"type":"object","properties":{"city":{"type":"string"}},"required":["city"]}`),
+	},
+}
+
+// ProbeChat 依次检查对话往返、流式输出、工具调用和上下文长度。
+// 对话往返失败时模型不可用，其余检查跳过。
+func ProbeChat(ctx context.Context, model chat.Chat, opts Options) *Report {
+	started := time.Now()
+	report := &Report{ModelName: model.GetModelName(), ModelType: types.ModelTypeKnowledgeQA}
+
+	report.Chat = checkChatRoundTrip(ctx, model, opts)
+	report.Available = report.Chat.Status == StatusPassed
+	if report.Available {
+		report.Streaming = checkStreaming(ctx, model, opts)
+		report.ToolCalls = checkToolCalls(ctx, model, opts)
+		report.Context = checkContextLength(ctx, model, opts)
+	} else {
+		skipped := Check{Status: StatusSkipped, Message: "对话检查未通过"}
+		report.Streaming = &StreamCheck{Check: skipped}
+		report.ToolCalls = &Check{Status: StatusSkipped, Message: skipped.Message}
+		report.Context = &ContextCheck{Check: skipped}
+	}
+
+	report.DurationMs = time.Since(started).Milliseconds()
+	return report
+}
+
+// ProbeEmbedding 对一段文本做向量化，检查连通性并探测向量维度
+func ProbeEmbedding(ctx context.Context, emb embedding.Embedder, opts Options) *Report {
+	started := time.Now()
+	report := &Report{ModelName: emb.GetModelName(), ModelType: types.ModelTypeEmbedding}
+
+	checkCtx, cancel := context.WithTimeout(ctx, opts.checkTimeout())
+	defer cancel()
+	callStarted := time.Now()
+	vec, err := emb.Embed(checkCtx, "hello")
+	result := &EmbeddingCheck{ConfiguredDimension: emb.GetDimensions()}
+	result.LatencyMs = time.Since(callStarted).Milliseconds()
+	switch {
+	case err != nil:
+		result.Status, result.Message = StatusFailed, err.Error()
+	case len(vec) == 0:
+		result.Status, result.Message = StatusFailed, "接口未返回向量"
+	default:
+		result.Status, result.Dimension = StatusPassed, len(vec)
+		if result.ConfiguredDimension > 0 && result.ConfiguredDimension != result.Dimension {
+			result.Message = fmt.Sprintf("配置的维度 %d 与实际返回的维度 %d 不一致",
+				result.ConfiguredDimension, result.Dimension)
+		}
+	}
+	report.Embedding = result
+	report.Available = result.Status == StatusPassed
+
+	report.DurationMs = time.Since(started).Milliseconds()
+	return report
+}
+
+// checkChatRoundTrip 发送一条简短消息，检查模型能否正常回答
+func checkChatRoundTrip(ctx context.Context, model chat.Chat, opts Options) *Check {
+	checkCtx, cancel := context.WithTimeout(ctx, opts.checkTimeout())
+	defer cancel()
+	started := time.Now()
+	_, err := model.Chat(checkCtx, []chat.Message{{Role: "user", Content: "Reply with OK."}}, &chat.ChatOptions{
+		MaxTokens: 16,
+		Thinking:  &[]bool{false}[0],
+	})
+	result := &Check{Status: StatusPassed, LatencyMs: time.Since(started).Milliseconds()}
+	if err != nil {
+		result.Status, result.Message = StatusFailed, err.Error()
+	}
+	return result
+}
+
+// checkStreaming 以流式调用模型，检查能否收到增量的内容分片
+func checkStreaming(ctx context.Context, model chat.Chat, opts Options) *StreamCheck {
+	checkCtx, cancel := context.WithTimeout(ctx, opts.checkTimeout())
+	defer cancel()
+	started := time.Now()
+	result := &StreamCheck{}
+	stream, err := model.ChatStream(checkCtx, []chat.Message{{Role: "user", Content: "Count from 1 to 10."}},
+		&chat.ChatOptions{MaxTokens: 64, Thinking: &[]bool{false}[0]})
+	if err != nil {
+		result.Status, result.Message = StatusFailed, err.Error()
+		result.LatencyMs = time.Since(started).Milliseconds()
+		return result
+	}
+
+	var streamErr string
+	for done := false; !done; {
+		select {
+		case event, ok := <-stream:
+			if !ok {
+				done = true
+				break
+			}
+			switch event.ResponseType {
+			case types.ResponseTypeAnswer, types.ResponseTypeThinking:
+				if event.Content == "" {
+					continue
+				}
+				if result.Chunks == 0 {
+					result.FirstTokenMs = time.Since(started).Milliseconds()
+				}
+				result.Chunks++
+			case types.ResponseTypeError:
+				streamErr = event.Content
+			}
+		case <-checkCtx.Done():
+			streamErr = checkCtx.Err().Error()
+			done = true
+		}
+	}
+	result.LatencyMs = time.Since(started).Milliseconds()
+
+	switch {
+	case streamErr != "":
+		result.Status, result.Message = StatusFailed, streamErr
+	case result.Chunks == 0:
+		result.Status, result.Message = StatusFailed, "流式调用未返回任何内容"
+	case result.Chunks == 1:
+		result.Status, result.Message = StatusPassed, "只收到 1 个内容分片，服务端可能将完整回答一次性返回"
+	default:
+		result.Status = StatusPassed
+	}
+	return result
+}
+
+// checkToolCalls 提供一个工具并要求模型调用，检查模型是否支持工具调用
+func checkToolCalls(ctx context.Context, model chat.Chat, opts Options) *Check {
+	checkCtx, cancel := context.WithTimeout(ctx, opts.checkTimeout())
+	defer cancel()
+	started := time.Now()
+	resp, err := model.Chat(checkCtx, []chat.Message{
+		{Role: "user", Content: "What is the weather in Paris right now? Use the get_weather tool."},
+	}, &chat.ChatOptions{
+		MaxTokens:  128,
+		Thinking:   &[]bool{false}[0],
+		Tools:      []chat.Tool{probeTool},
+		ToolChoice: "auto",
+	})
+	result := &Check{LatencyMs: time.Since(started).Milliseconds()}
+	switch {
+	case err != nil && isRequestRejected(err):
+		// 不支持工具的模型或服务通常直接以 400 拒绝带 tools 的请求
+		result.Status, result.Message = StatusUnsupported, err.Error()
+	case err != nil:
+		result.Status, result.Message = StatusFailed, err.Error()
+	case len(resp.ToolCalls) == 0:
+		result.Status, result.Message = StatusUnsupported, "模型没有调用工具，而是直接回答"
+	case resp.ToolCalls[0].Function.Name != probeTool.Function.Name:
+		result.Status = StatusUnsupported
+		result.Message = fmt.Sprintf("模型调用了不存在的工具 %q", resp.ToolCalls[0].Function.Name)
+	default:
+		result.Status = StatusPassed
+	}
+	return result
+}
+
+// checkContextLength 优先使用推理服务上报的上下文长度；未上报且开启 ProbeContext 时，
+// 从 minContextProbeTokens 起逐次翻倍发送长提示词，直到超出上下文或达到上限，
+// 得到的是模型至少支持的长度。
+func checkContextLength(ctx context.Context, model chat.Chat, opts Options) *ContextCheck {
+	started := time.Now()
+	if tokens := chat.ContextLength(ctx, model); tokens > 0 {
+		return &ContextCheck{
+			Check:  Check{Status: StatusPassed, LatencyMs: time.Since(started).Milliseconds()},
+			Tokens: tokens,
+			Source: ContextSourceReported,
+		}
+	}
+	if !opts.ProbeContext {
+		return &ContextCheck{Check: Check{Status: StatusSkipped, Message: "服务未上报上下文长度，可开启实测"}}
+	}
+
+	result := &ContextCheck{Source: ContextSourceProbed}
+	limit := opts.maxContextTokens()
+	var lastErr error
+	var failedAt int
+	for size := min(minContextProbeTokens, limit); ; size = min(size*2, limit) {
+		checkCtx, cancel := context.WithTimeout(ctx, opts.checkTimeout())
+		_, err := model.Chat(checkCtx, []chat.Message{{Role: "user", Content: contextFiller(size)}},
+			&chat.ChatOptions{MaxTokens: 1, Thinking: &[]bool{false}[0]})
+		cancel()
+		if err != nil {
+			lastErr, failedAt = err, size
+			break
+		}
+		result.Tokens = size
+		if size >= limit {
+			break
+		}
+	}
+	result.LatencyMs = time.Since(started).Milliseconds()
+
+	switch {
+	case lastErr == nil:
+		result.Status = StatusPassed
+		result.Message = fmt.Sprintf("已达到实测上限，模型至少支持 %d tokens", result.Tokens)
+	case !isContextLengthError(lastErr):
+		result.Status, result.Message = StatusFailed, lastErr.Error()
+	case result.Tokens == 0:
+		result.Status = StatusFailed
+		result.Message = fmt.Sprintf("上下文长度不足 %d tokens：%v", failedAt, lastErr)
+	default:
+		result.Status = StatusPassed
+		result.Message = fmt.Sprintf("模型至少支持 %d tokens，%d tokens 时超出上下文", result.Tokens, failedAt)
+	}
+	return result
+}
+
+// contextFiller 生成约 tokens 个 token 的提示词，为提示模板和回答预留少量余量
+func contextFiller(tokens int) string {
+	const reserved = 64
+	words := max(tokens-reserved, 1)
+	var b strings.Builder
+	b.Grow(words*len("hello ") + 32)
+	for range words {
+		b.WriteString("hello ")
+	}
+	b.WriteString("\nReply with OK.")
+	return b.String()
+}
+
+// isRequestRejected 判断错误是否为服务端拒绝了请求参数（而不是网络或鉴权问题）
+func isRequestRejected(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "400") || strings.Contains(msg, "422") ||
+		strings.Contains(msg, "not support") || strings.Contains(msg, "unsupported")
+}
+
+// isContextLengthError 判断错误是否由提示词超出上下文长度引起
+func isContextLengthError(err error) bool {
+	msg := strings.ToLower(err.Error())
+	for _, marker := range []string{
+		"context length", "context_length", "context window", "maximum context",
+		"too long", "too many tokens", "reduce the length", "exceeds the limit", "max_model_len",
+	} {
+		if strings.Contains(msg, marker) {
+			return true
+		}
+	}
+	return false
+}
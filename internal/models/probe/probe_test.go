@@ -0,0 +1,173 @@
+package probe
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/chat"
+	"github.com/Tencent/WeKnora/internal/models/embedding"
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeChat is a chat model whose behaviour is scripted per test.
+type fakeChat struct {
+	chatErr      error
+	toolCalls    []types.LLMToolCall
+	toolErr      error
+	streamChunks []string
+	contextLimit int // prompts longer than this many words fail; 0 means unlimited
+}
+
+func (f *fakeChat) Chat(ctx context.Context, messages []chat.Message, opts *chat.ChatOptions) (*types.ChatResponse, error) {
+	if f.chatErr != nil {
+		return nil, f.chatErr
+	}
+	if len(opts.Tools) > 0 {
+		if f.toolErr != nil {
+			return nil, f.toolErr
+		}
+		return &types.ChatResponse{ToolCalls: f.toolCalls}, nil
+	}
+	if words := len(strings.Fields(messages[0].Content)); f.contextLimit > 0 && words > f.contextLimit {
+		return nil, errors.New("This model's maximum context length is exceeded, please reduce the length of the messages")
+	}
+	return &types.ChatResponse{Content: "OK"}, nil
+}
+
+func (f *fakeChat) ChatStream(ctx context.Context, messages []chat.Message, opts *chat.ChatOptions) (<-chan types.StreamResponse, error) {
+	ch := make(chan types.StreamResponse, len(f.streamChunks)+1)
+	for _, chunk := range f.streamChunks {
+		ch <- types.StreamResponse{ResponseType: types.ResponseTypeAnswer, Content: chunk}
+	}
+	ch <- types.StreamResponse{ResponseType: types.ResponseTypeAnswer, Done: true}
+	close(ch)
+	return ch, nil
+}
+
+func (f *fakeChat) GetModelName() string { return "fake-chat" }
+func (f *fakeChat) GetModelID() string   { return "" }
+
+// reportingChat reports its context length like a self-hosted replica.
+type reportingChat struct {
+	fakeChat
+	contextLength int
+}
+
+func (r *reportingChat) ContextLength(ctx context.Context) int { return r.contextLength }
+
+func weatherCall() []types.LLMToolCall {
+	return []types.LLMToolCall{{ID: "1", Type: "function", Function: types.FunctionCall{Name: "get_weather"}}}
+}
+
+func TestProbeChat_ReportsCapabilities(t *testing.T) {
+	model := &reportingChat{
+		fakeChat:      fakeChat{toolCalls: weatherCall(), streamChunks: []string{"1", ", 2", ", 3"}},
+		contextLength: 8192,
+	}
+
+	report := ProbeChat(context.Background(), model, Options{})
+	assert.True(t, report.Available)
+	assert.Equal(t, types.ModelTypeKnowledgeQA, report.ModelType)
+	assert.Equal(t, StatusPassed, report.Chat.Status)
+	assert.Equal(t, StatusPassed, report.Streaming.Status)
+	assert.Equal(t, 3, report.Streaming.Chunks)
+	assert.Equal(t, StatusPassed, report.ToolCalls.Status)
+	assert.Equal(t, StatusPassed, report.Context.Status)
+	assert.Equal(t, 8192, report.Context.Tokens)
+	assert.Equal(t, ContextSourceReported, report.Context.Source)
+	assert.Nil(t, report.Embedding)
+}
+
+func TestProbeChat_SkipsChecksWhenChatFails(t *testing.T) {
+	report := ProbeChat(context.Background(), &fakeChat{chatErr: errors.New("401 unauthorized")}, Options{})
+	assert.False(t, report.Available)
+	assert.Equal(t, StatusFailed, report.Chat.Status)
+	assert.Contains(t, report.Chat.Message, "401")
+	assert.Equal(t, StatusSkipped, report.Streaming.Status)
+	assert.Equal(t, StatusSkipped, report.ToolCalls.Status)
+	assert.Equal(t, StatusSkipped, report.Context.Status)
+}
+
+func TestProbeChat_ToolCallSupport(t *testing.T) {
+	tests := []struct {
+		name  string
+		model *fakeChat
+		want  Status
+	}{
+		{"calls the tool", &fakeChat{toolCalls: weatherCall()}, StatusPassed},
+		{"answers directly", &fakeChat{}, StatusUnsupported},
+		{"rejects tools", &fakeChat{toolErr: errors.New("status code: 400, model does not support tools")}, StatusUnsupported},
+		{"times out", &fakeChat{toolErr: context.DeadlineExceeded}, StatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.model.streamChunks = []string{"1", "2"}
+			report := ProbeChat(context.Background(), tt.model, Options{})
+			assert.Equal(t, tt.want, report.ToolCalls.Status, report.ToolCalls.Message)
+		})
+	}
+}
+
+func TestProbeChat_StreamingWithoutContent(t *testing.T) {
+	report := ProbeChat(context.Background(), &fakeChat{}, Options{})
+	assert.True(t, report.Available)
+	assert.Equal(t, StatusFailed, report.Streaming.Status)
+}
+
+func TestProbeChat_ContextProbing(t *testing.T) {
+	// Not probed unless asked to: it sends long prompts.
+	report := ProbeChat(context.Background(), &fakeChat{streamChunks: []string{"1"}}, Options{})
+	assert.Equal(t, StatusSkipped, report.Context.Status)
+
+	report = ProbeChat(context.Background(), &fakeChat{contextLimit: 10000}, Options{ProbeContext: true})
+	require.Equal(t, StatusPassed, report.Context.Status, report.Context.Message)
+	assert.Equal(t, 8192, report.Context.Tokens)
+	assert.Equal(t, ContextSourceProbed, report.Context.Source)
+
+	// The probe stops at the configured ceiling.
+	report = ProbeChat(context.Background(), &fakeChat{}, Options{ProbeContext: true, MaxContextTokens: 10000})
+	require.Equal(t, StatusPassed, report.Context.Status)
+	assert.Equal(t, 10000, report.Context.Tokens)
+
+	report = ProbeChat(context.Background(), &fakeChat{contextLimit: 2000}, Options{ProbeContext: true})
+	assert.Equal(t, StatusFailed, report.Context.Status)
+	assert.Equal(t, 0, report.Context.Tokens)
+}
+
+// fakeEmbedder returns vectors of a fixed dimension.
+type fakeEmbedder struct {
+	embedding.Embedder
+	dimension  int
+	configured int
+	err        error
+}
+
+func (f *fakeEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	return make([]float32, f.dimension), nil
+}
+
+func (f *fakeEmbedder) GetModelName() string { return "fake-embedding" }
+func (f *fakeEmbedder) GetDimensions() int   { return f.configured }
+
+func TestProbeEmbedding(t *testing.T) {
+	report := ProbeEmbedding(context.Background(), &fakeEmbedder{dimension: 1024}, Options{})
+	assert.True(t, report.Available)
+	assert.Equal(t, types.ModelTypeEmbedding, report.ModelType)
+	assert.Equal(t, 1024, report.Embedding.Dimension)
+	assert.Empty(t, report.Embedding.Message)
+
+	report = ProbeEmbedding(context.Background(), &fakeEmbedder{dimension: 1024, configured: 768}, Options{})
+	assert.True(t, report.Available)
+	assert.Contains(t, report.Embedding.Message, "768")
+
+	report = ProbeEmbedding(context.Background(), &fakeEmbedder{err: errors.New("dial tcp: connection refused")}, Options{})
+	assert.False(t, report.Available)
+	assert.Equal(t, StatusFailed, report.Embedding.Status)
+}
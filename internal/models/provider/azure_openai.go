@@ -2,10 +2,20 @@ package provider
 
 import (
 	"fmt"
+	"net/url"
+	"strings"
 
 	"github.com/Tencent/WeKnora/internal/types"
 )
 
+const (
+	// AzureOpenAIDefaultAPIVersion 未配置 api_version 时使用的 Azure OpenAI API 版本
+	AzureOpenAIDefaultAPIVersion = "2024-10-21"
+	// AzureOpenAIV1APIVersion 表示使用 Azure OpenAI v1 API：地址为 {endpoint}/openai/v1，
+	// 不带 api-version 参数，请求体的 model 字段即部署名
+	AzureOpenAIV1APIVersion = "v1"
+)
+
 // AzureOpenAIProvider 实现 Azure OpenAI 的 Provider 接口
 type AzureOpenAIProvider struct{}
 
@@ -39,8 +49,15 @@ func (p *AzureOpenAIProvider) Info() ProviderInfo {
 				Label:       "API Version",
 				Type:        "string",
 				Required:    false,
-				Default:     "2024-10-21",
-				Placeholder: "e.g. 2024-10-21",
+				Default:     AzureOpenAIDefaultAPIVersion,
+				Placeholder: "e.g. 2024-10-21, or v1",
+			},
+			{
+				Key:         "deployment_name",
+				Label:       "Deployment Name",
+				Type:        "string",
+				Required:    false,
+				Placeholder: "defaults to the model name",
 			},
 		},
 	}
@@ -59,3 +76,46 @@ func (p *AzureOpenAIProvider) ValidateConfig(config *Config) error {
 	}
 	return nil
 }
+
+// AzureOpenAIDeployment 返回请求路由使用的部署名：优先取 extra_config.deployment_name，
+// 未配置时沿用模型名（模型名即部署名的旧配置方式）。
+func AzureOpenAIDeployment(modelName string, extra map[string]string) string {
+	if deployment := strings.TrimSpace(extra["deployment_name"]); deployment != "" {
+		return deployment
+	}
+	return modelName
+}
+
+// AzureOpenAIAPIVersion 返回 extra_config.api_version，未配置时使用默认版本。
+func AzureOpenAIAPIVersion(extra map[string]string) string {
+	if version := strings.TrimSpace(extra["api_version"]); version != "" {
+		return version
+	}
+	return AzureOpenAIDefaultAPIVersion
+}
+
+// AzureOpenAIEndpoint 返回 Azure 资源地址，去掉用户可能多填的 /openai 或 /openai/v1 后缀。
+func AzureOpenAIEndpoint(baseURL string) string {
+	endpoint := strings.TrimRight(baseURL, "/")
+	endpoint = strings.TrimSuffix(endpoint, "/openai/v1")
+	return strings.TrimSuffix(endpoint, "/openai")
+}
+
+// AzureOpenAIURL 拼接 Azure OpenAI 接口地址。v1 API 为 {endpoint}/openai/v1/{operation}；
+// 其余版本按部署路由为 {endpoint}/openai/deployments/{deployment}/{operation}?api-version={version}。
+// operation 为空时返回对应的 base 地址（不带 api-version）。
+func AzureOpenAIURL(baseURL, deployment, apiVersion, operation string) string {
+	endpoint := AzureOpenAIEndpoint(baseURL)
+	if apiVersion == AzureOpenAIV1APIVersion {
+		base := endpoint + "/openai/v1"
+		if operation == "" {
+			return base
+		}
+		return base + "/" + operation
+	}
+	base := endpoint + "/openai/deployments/" + url.PathEscape(deployment)
+	if operation == "" {
+		return base
+	}
+	return base + "/" + operation + "?api-version=" + url.QueryEscape(apiVersion)
+}
@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/models/utils"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	// BedrockBaseURL Amazon Bedrock Runtime 地址模板，{region} 为所在区域
+	BedrockBaseURL = "https://bedrock-runtime.{region}.amazonaws.com"
+	// BedrockDefaultRegion 未配置区域且无法从 BaseURL 推断时使用的区域
+	BedrockDefaultRegion = "us-east-1"
+)
+
+// BedrockProvider 实现 Amazon Bedrock 的 Provider 接口
+type BedrockProvider struct{}
+
+func init() {
+	Register(&BedrockProvider{})
+}
+
+// Info 返回 Amazon Bedrock provider 的元数据
+func (p *BedrockProvider) Info() ProviderInfo {
+	return ProviderInfo{
+		Name:        ProviderBedrock,
+		DisplayName: "Amazon Bedrock",
+		Description: "anthropic.claude-sonnet-4, amazon.nova-pro, amazon.titan-embed-text-v2, cohere.rerank-v3-5, etc.",
+		DefaultURLs: map[types.ModelType]string{
+			types.ModelTypeKnowledgeQA: BedrockBaseURL,
+			types.ModelTypeEmbedding:   BedrockBaseURL,
+			types.ModelTypeRerank:      BedrockBaseURL,
+		},
+		ModelTypes: []types.ModelType{
+			types.ModelTypeKnowledgeQA,
+			types.ModelTypeEmbedding,
+			types.ModelTypeRerank,
+		},
+		RequiresAuth: true,
+		ExtraFields: []ExtraFieldConfig{
+			{
+				Key:         "region",
+				Label:       "Region",
+				Type:        "string",
+				Required:    false,
+				Default:     BedrockDefaultRegion,
+				Placeholder: "e.g. us-east-1",
+			},
+			{
+				Key:         "access_key_id",
+				Label:       "Access Key ID",
+				Type:        "string",
+				Required:    false,
+				Placeholder: "set to sign with SigV4; the API key is then the secret access key",
+			},
+			{
+				Key:         "session_token",
+				Label:       "Session Token",
+				Type:        "string",
+				Required:    false,
+				Placeholder: "for temporary credentials",
+			},
+		},
+	}
+}
+
+// ValidateConfig 验证 Amazon Bedrock provider 配置
+func (p *BedrockProvider) ValidateConfig(config *Config) error {
+	if config.APIKey == "" {
+		return fmt.Errorf("API key or secret access key is required for Amazon Bedrock provider")
+	}
+	if config.ModelName == "" {
+		return fmt.Errorf("model ID (model name) is required")
+	}
+	return nil
+}
+
+// BedrockRegion 返回请求所在区域：优先取 extra_config.region，
+// 其次从 bedrock-runtime.{region}.amazonaws.com 形式的 BaseURL 推断。
+func BedrockRegion(baseURL string, extra map[string]string) string {
+	if region := strings.TrimSpace(extra["region"]); region != "" {
+		return region
+	}
+	if u, err := url.Parse(baseURL); err == nil {
+		labels := strings.Split(u.Hostname(), ".")
+		for i := 0; i+1 < len(labels); i++ {
+			if strings.HasPrefix(labels[i], "bedrock-runtime") && labels[i+1] != "{region}" && labels[i+1] != "amazonaws" {
+				return labels[i+1]
+			}
+		}
+	}
+	return BedrockDefaultRegion
+}
+
+// BedrockRuntimeURL 返回 Bedrock Runtime 地址；BaseURL 为空或仍是地址模板时按区域生成。
+func BedrockRuntimeURL(baseURL, region string) string {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if baseURL == "" || strings.Contains(baseURL, "{region}") {
+		return strings.Replace(BedrockBaseURL, "{region}", region, 1)
+	}
+	return baseURL
+}
+
+// BedrockAuth 根据模型配置构造 Bedrock 请求的鉴权：配置了 extra_config.access_key_id 时
+// apiKey 为 secret access key，按 SigV4 签名；否则 apiKey 为 Bedrock API key。
+func BedrockAuth(apiKey, region string, extra map[string]string) utils.AWSAuth {
+	auth := utils.AWSAuth{Service: "bedrock", Region: region}
+	if accessKeyID := strings.TrimSpace(extra["access_key_id"]); accessKeyID != "" {
+		auth.AccessKeyID = accessKeyID
+		auth.SecretAccessKey = apiKey
+		auth.SessionToken = strings.TrimSpace(extra["session_token"])
+		return auth
+	}
+	auth.APIKey = apiKey
+	return auth
+}
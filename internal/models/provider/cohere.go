@@ -0,0 +1,42 @@
+package provider
+
+import (
+	"fmt"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+const (
+	CohereBaseURL = "https://api.cohere.com/v2"
+)
+
+// CohereProvider 实现 Cohere 的 Provider 接口
+type CohereProvider struct{}
+
+func init() {
+	Register(&CohereProvider{})
+}
+
+// Info 返回 Cohere provider 的元数据
+func (p *CohereProvider) Info() ProviderInfo {
+	return ProviderInfo{
+		Name:        ProviderCohere,
+		DisplayName: "Cohere",
+		Description: "rerank-v3.5, rerank-multilingual-v3.0, etc.",
+		DefaultURLs: map[types.ModelType]string{
+			types.ModelTypeRerank: CohereBaseURL,
+		},
+		ModelTypes: []types.ModelType{
+			types.ModelTypeRerank,
+		},
+		RequiresAuth: true,
+	}
+}
+
+// ValidateConfig 验证 Cohere provider 配置
+func (p *CohereProvider) ValidateConfig(config *Config) error {
+	if config.APIKey == "" {
+		return fmt.Errorf("API key is required for Cohere provider")
+	}
+	return nil
+}
@@ -9,7 +9,8 @@ import (
 const (
 	// GeminiBaseURL Google Gemini API BaseURL
 	GeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
-	// GeminiOpenAICompatBaseURL Gemini OpenAI 兼容模式 BaseURL
+	// GeminiOpenAICompatBaseURL Gemini OpenAI 兼容模式 BaseURL；对话模型配置为该地址时
+	// 按 OpenAI 兼容协议调用，否则使用原生 generateContent 协议
 	GeminiOpenAICompatBaseURL = "https://generativelanguage.googleapis.com/v1beta/openai"
 )
 
@@ -27,7 +28,7 @@ func (p *GeminiProvider) Info() ProviderInfo {
 		DisplayName: "Google Gemini",
 		Description: "gemini-3-flash-preview, gemini-2.5-pro, gemini-embedding-2, etc.",
 		DefaultURLs: map[types.ModelType]string{
-			types.ModelTypeKnowledgeQA: GeminiOpenAICompatBaseURL,
+			types.ModelTypeKnowledgeQA: GeminiBaseURL,
 			types.ModelTypeEmbedding:   GeminiBaseURL,
 		},
 		ModelTypes: []types.ModelType{
@@ -27,6 +27,8 @@ const (
 	ProviderSiliconFlow ProviderName = "siliconflow"
 	// Jina AI (Embedding and Rerank)
 	ProviderJina ProviderName = "jina"
+	// Cohere (Rerank)
+	ProviderCohere ProviderName = "cohere"
 	// Generic 兼容OpenAI (自定义部署)
 	ProviderGeneric ProviderName = "generic"
 	// DeepSeek
@@ -61,6 +63,10 @@ const (
 	ProviderNovita ProviderName = "novita"
 	// Azure OpenAI
 	ProviderAzureOpenAI ProviderName = "azure_openai"
+	// Amazon Bedrock
+	ProviderBedrock ProviderName = "bedrock"
+	// 自托管 OpenAI 兼容推理服务（vLLM、llama.cpp server 等）
+	ProviderSelfHosted ProviderName = "self_hosted"
 )
 
 // AllProviders 返回所有注册的提供者名称
@@ -84,6 +90,7 @@ func AllProviders() []ProviderName {
 		ProviderGemini,
 		ProviderOpenRouter,
 		ProviderJina,
+		ProviderCohere,
 		ProviderMimo,
 		ProviderLongCat,
 		ProviderLKEAP,
@@ -91,6 +98,8 @@ func AllProviders() []ProviderName {
 		ProviderNvidia,
 		ProviderNovita,
 		ProviderAzureOpenAI,
+		ProviderBedrock,
+		ProviderSelfHosted,
 	}
 }
 
@@ -228,8 +237,12 @@ func DetectProvider(baseURL string) ProviderName {
 		return ProviderSiliconFlow
 	case containsAny(baseURL, "api.jina.ai"):
 		return ProviderJina
+	case containsAny(baseURL, "api.cohere.com", "api.cohere.ai"):
+		return ProviderCohere
 	case containsAny(baseURL, "openai.azure.com"):
 		return ProviderAzureOpenAI
+	case containsAny(baseURL, "bedrock-runtime"):
+		return ProviderBedrock
 	case containsAny(baseURL, "api.openai.com"):
 		return ProviderOpenAI
 	case containsAny(baseURL, "api.anthropic.com"):
@@ -51,6 +51,9 @@ func TestDetectProvider(t *testing.T) {
 		{"http://localhost:11434/v1", ProviderGeneric},
 		{"https://integrate.api.nvidia.com/v1", ProviderNvidia},
 		{"https://ai.api.nvidia.com/v1/retrieval/nvidia/reranking", ProviderNvidia},
+		{"https://api.cohere.com/v2", ProviderCohere},
+		{"https://example.openai.azure.com", ProviderAzureOpenAI},
+		{"https://bedrock-runtime.us-west-2.amazonaws.com", ProviderBedrock},
 	}
 
 	for _, tt := range tests {
@@ -278,3 +281,50 @@ func TestListByModelType(t *testing.T) {
 		assert.True(t, found, "Gemini should support embedding via the native Gemini API")
 	})
 }
+
+func TestAzureOpenAIURL(t *testing.T) {
+	extra := map[string]string{"deployment_name": " prod-gpt "}
+	assert.Equal(t, "prod-gpt", AzureOpenAIDeployment("gpt-4o", extra))
+	assert.Equal(t, "gpt-4o", AzureOpenAIDeployment("gpt-4o", nil))
+	assert.Equal(t, AzureOpenAIDefaultAPIVersion, AzureOpenAIAPIVersion(nil))
+
+	assert.Equal(t,
+		"https://res.openai.azure.com/openai/deployments/prod-gpt/chat/completions?api-version=2025-04-01-preview",
+		AzureOpenAIURL("https://res.openai.azure.com/", "prod-gpt", "2025-04-01-preview", "chat/completions"))
+	assert.Equal(t,
+		"https://res.openai.azure.com/openai/v1/embeddings",
+		AzureOpenAIURL("https://res.openai.azure.com/openai/v1", "prod-gpt", AzureOpenAIV1APIVersion, "embeddings"))
+	assert.Equal(t, "https://res.openai.azure.com", AzureOpenAIEndpoint("https://res.openai.azure.com/openai/"))
+}
+
+func TestBedrockRegionAndAuth(t *testing.T) {
+	assert.Equal(t, "eu-central-1", BedrockRegion("https://bedrock-runtime.eu-central-1.amazonaws.com", nil))
+	assert.Equal(t, "us-west-2", BedrockRegion("https://bedrock-runtime.eu-central-1.amazonaws.com",
+		map[string]string{"region": "us-west-2"}))
+	assert.Equal(t, "us-gov-west-1", BedrockRegion("https://bedrock-runtime-fips.us-gov-west-1.amazonaws.com", nil))
+	assert.Equal(t, BedrockDefaultRegion, BedrockRegion(BedrockBaseURL, nil))
+	assert.Equal(t, BedrockDefaultRegion, BedrockRegion("https://vpce-123.example.com", nil))
+
+	assert.Equal(t, "https://bedrock-runtime.ap-south-1.amazonaws.com", BedrockRuntimeURL(BedrockBaseURL, "ap-south-1"))
+	assert.Equal(t, "https://vpce-123.example.com", BedrockRuntimeURL("https://vpce-123.example.com/", "ap-south-1"))
+
+	sigv4 := BedrockAuth("secret", "us-east-1", map[string]string{"access_key_id": "AKIDEXAMPLE", "session_token": "tok"})
+	assert.Equal(t, "AKIDEXAMPLE", sigv4.AccessKeyID)
+	assert.Equal(t, "secret", sigv4.SecretAccessKey)
+	assert.Equal(t, "tok", sigv4.SessionToken)
+	assert.Empty(t, sigv4.APIKey)
+
+	bearer := BedrockAuth("bedrock-api-key", "us-east-1", nil)
+	assert.Equal(t, "bedrock-api-key", bearer.APIKey)
+	assert.Empty(t, bearer.AccessKeyID)
+}
+
+func TestSelfHostedEndpoints(t *testing.T) {
+	endpoints := SelfHostedEndpoints("http://10.0.0.1:8000/v1/", map[string]string{
+		"endpoints": "http://10.0.0.2:8000/v1, http://10.0.0.1:8000/v1\nhttp://10.0.0.3:8000/v1/,",
+	})
+	assert.Equal(t, []string{"http://10.0.0.1:8000/v1", "http://10.0.0.2:8000/v1", "http://10.0.0.3:8000/v1"}, endpoints)
+
+	assert.Empty(t, SelfHostedHealthCheckPath(nil))
+	assert.Equal(t, "/healthz", SelfHostedHealthCheckPath(map[string]string{"health_check_path": "healthz"}))
+}
@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// SelfHostedBaseURL 自托管推理服务（vLLM / llama.cpp server）OpenAI 兼容地址示例
+const SelfHostedBaseURL = "http://your_inference_server:8000/v1"
+
+// SelfHostedProvider 实现自托管 OpenAI 兼容推理服务的 Provider 接口。
+// 同一模型可部署多个副本：BaseURL 之外的副本地址写在 extra_config.endpoints 中，
+// 请求按健康状态和在途请求数在副本间路由。
+type SelfHostedProvider struct{}
+
+func init() {
+	Register(&SelfHostedProvider{})
+}
+
+// Info 返回自托管推理服务 provider 的元数据
+func (p *SelfHostedProvider) Info() ProviderInfo {
+	return ProviderInfo{
+		Name:        ProviderSelfHosted,
+		DisplayName: "Self-hosted (vLLM / llama.cpp)",
+		Description: "OpenAI-compatible vLLM or llama.cpp servers, load-balanced across replicas",
+		DefaultURLs: map[types.ModelType]string{
+			types.ModelTypeKnowledgeQA: SelfHostedBaseURL,
+			types.ModelTypeEmbedding:   SelfHostedBaseURL,
+		},
+		ModelTypes: []types.ModelType{
+			types.ModelTypeKnowledgeQA,
+			types.ModelTypeEmbedding,
+		},
+		RequiresAuth: false,
+		ExtraFields: []ExtraFieldConfig{
+			{
+				Key:         "endpoints",
+				Label:       "Replica Endpoints",
+				Type:        "string",
+				Required:    false,
+				Placeholder: "other replicas, comma separated, e.g. http://10.0.0.2:8000/v1",
+			},
+			{
+				Key:         "health_check_path",
+				Label:       "Health Check Path",
+				Type:        "string",
+				Required:    false,
+				Default:     "/health",
+				Placeholder: "relative to the server root, e.g. /health",
+			},
+		},
+	}
+}
+
+// ValidateConfig 验证自托管推理服务 provider 配置
+func (p *SelfHostedProvider) ValidateConfig(config *Config) error {
+	if config.BaseURL == "" {
+		return fmt.Errorf("base URL is required for self-hosted provider")
+	}
+	if config.ModelName == "" {
+		return fmt.Errorf("model name is required")
+	}
+	return nil
+}
+
+// SelfHostedEndpoints 返回模型的全部副本地址：BaseURL 在前，随后是 extra_config.endpoints
+// 中以逗号或换行分隔的地址；去除末尾斜杠并去重。
+func SelfHostedEndpoints(baseURL string, extra map[string]string) []string {
+	var endpoints []string
+	seen := make(map[string]bool)
+	add := func(endpoint string) {
+		endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+		if endpoint == "" || seen[endpoint] {
+			return
+		}
+		seen[endpoint] = true
+		endpoints = append(endpoints, endpoint)
+	}
+	add(baseURL)
+	for _, endpoint := range strings.FieldsFunc(extra["endpoints"], func(r rune) bool {
+		return r == ',' || r == '\n'
+	}) {
+		add(endpoint)
+	}
+	return endpoints
+}
+
+// SelfHostedHealthCheckPath 返回 extra_config.health_check_path，未配置时返回空串（使用默认路径）
+func SelfHostedHealthCheckPath(extra map[string]string) string {
+	path := strings.TrimSpace(extra["health_check_path"])
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
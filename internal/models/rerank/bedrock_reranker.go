@@ -0,0 +1,149 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/models/utils"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// BedrockReranker implements reranking with the Amazon Rerank and Cohere
+// Rerank models on Amazon Bedrock, through the InvokeModel API.
+type BedrockReranker struct {
+	modelName     string
+	modelID       string
+	baseURL       string
+	auth          utils.AWSAuth
+	client        *http.Client
+	customHeaders map[string]string
+}
+
+// SetCustomHeaders 设置用户自定义 HTTP 请求头（类似 OpenAI Python SDK 的 extra_headers）。
+func (r *BedrockReranker) SetCustomHeaders(headers map[string]string) {
+	r.customHeaders = headers
+}
+
+// BedrockRerankRequest is the InvokeModel body of a Bedrock rerank model
+type BedrockRerankRequest struct {
+	Query      string   `json:"query"`
+	Documents  []string `json:"documents"`
+	TopN       int      `json:"top_n"`
+	APIVersion int      `json:"api_version,omitempty"` // required by Cohere models
+}
+
+// BedrockRerankResponse is the response of a Bedrock rerank model, which
+// only carries the indices of the documents.
+type BedrockRerankResponse struct {
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// NewBedrockReranker creates a new instance of Bedrock reranker with the provided configuration
+func NewBedrockReranker(config *RerankerConfig) (*BedrockReranker, error) {
+	if strings.TrimSpace(config.APIKey) == "" {
+		return nil, fmt.Errorf("Bedrock reranker: API key or secret access key is required")
+	}
+	region := provider.BedrockRegion(config.BaseURL, config.ExtraConfig)
+	baseURL := provider.BedrockRuntimeURL(config.BaseURL, region)
+	if baseURL != provider.BedrockRuntimeURL("", region) {
+		if err := secutils.ValidateURLForSSRF(baseURL); err != nil {
+			return nil, fmt.Errorf("baseURL SSRF check failed: %w", err)
+		}
+	}
+
+	return &BedrockReranker{
+		modelName: config.ModelName,
+		modelID:   config.ModelID,
+		baseURL:   baseURL,
+		auth:      provider.BedrockAuth(config.APIKey, region, config.ExtraConfig),
+		client:    &http.Client{},
+	}, nil
+}
+
+// Rerank performs document reranking based on relevance to the query
+func (r *BedrockReranker) Rerank(ctx context.Context, query string, documents []string) ([]RankResult, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+	requestBody := &BedrockRerankRequest{
+		Query:     query,
+		Documents: documents,
+		TopN:      len(documents),
+	}
+	if strings.Contains(r.modelName, "cohere.rerank") {
+		requestBody.APIVersion = 2
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request body: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/model/%s/invoke", r.baseURL, url.PathEscape(r.modelName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	secutils.ApplyCustomHeaders(req, r.customHeaders)
+	if err := r.auth.Apply(ctx, req, jsonData); err != nil {
+		return nil, err
+	}
+
+	logger.Debugf(ctx, "%s", buildRerankRequestDebug(r.modelName, endpoint, query, documents))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		logger.GetLogger(ctx).Errorf("BedrockReranker API error: Http Status: %s, Body: %s", resp.Status, string(body))
+		return nil, fmt.Errorf("Rerank API error: Http Status: %s", resp.Status)
+	}
+
+	var response BedrockRerankResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	ret := make([]RankResult, 0, len(response.Results))
+	for _, result := range response.Results {
+		if result.Index < 0 || result.Index >= len(documents) {
+			continue
+		}
+		ret = append(ret, RankResult{
+			Index:          result.Index,
+			Document:       DocumentInfo{Text: documents[result.Index]},
+			RelevanceScore: result.RelevanceScore,
+		})
+	}
+	return ret, nil
+}
+
+// GetModelName returns the name of the reranking model
+func (r *BedrockReranker) GetModelName() string {
+	return r.modelName
+}
+
+// GetModelID returns the unique identifier of the reranking model
+func (r *BedrockReranker) GetModelID() string {
+	return r.modelID
+}
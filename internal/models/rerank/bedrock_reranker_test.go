@@ -0,0 +1,50 @@
+package rerank
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBedrockReranker_Rerank(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/model/cohere.rerank-v3-5:0/invoke", r.URL.Path)
+		assert.Contains(t, r.Header.Get("Authorization"), "/ap-northeast-1/bedrock/aws4_request")
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+		var req BedrockRerankRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "query", req.Query)
+		assert.Equal(t, []string{"a", "b"}, req.Documents)
+		assert.Equal(t, 2, req.TopN)
+		assert.Equal(t, 2, req.APIVersion)
+		_, _ = w.Write([]byte(`{"results":[{"index":1,"relevance_score":0.9},{"index":0,"relevance_score":0.2}]}`))
+	}))
+	defer srv.Close()
+
+	r, err := newReranker(&RerankerConfig{
+		APIKey:    "secret",
+		BaseURL:   srv.URL,
+		ModelName: "cohere.rerank-v3-5:0",
+		Provider:  string(provider.ProviderBedrock),
+		ExtraConfig: map[string]string{
+			"region":        "ap-northeast-1",
+			"access_key_id": "AKIDEXAMPLE",
+			"session_token": "session",
+		},
+	})
+	require.NoError(t, err)
+	require.IsType(t, &BedrockReranker{}, r)
+
+	results, err := r.Rerank(t.Context(), "query", []string{"a", "b"})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "b", results[0].Document.Text)
+	assert.InDelta(t, 0.9, results[0].RelevanceScore, 1e-9)
+}
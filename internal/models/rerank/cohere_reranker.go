@@ -0,0 +1,134 @@
+package rerank
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+// CohereReranker implements a reranking system using the Cohere v2 Rerank API
+type CohereReranker struct {
+	modelName     string       // Name of the model used for reranking
+	modelID       string       // Unique identifier of the model
+	apiKey        string       // API key for authentication
+	baseURL       string       // Base URL for API requests
+	client        *http.Client // HTTP client for making API requests
+	customHeaders map[string]string
+}
+
+// SetCustomHeaders 设置用户自定义 HTTP 请求头（类似 OpenAI Python SDK 的 extra_headers）。
+func (r *CohereReranker) SetCustomHeaders(headers map[string]string) {
+	r.customHeaders = headers
+}
+
+// CohereRerankRequest represents a Cohere rerank request
+type CohereRerankRequest struct {
+	Model     string   `json:"model"`           // Model to use for reranking
+	Query     string   `json:"query"`           // Query text to compare documents against
+	Documents []string `json:"documents"`       // List of document texts to rerank
+	TopN      int      `json:"top_n,omitempty"` // Number of top results to return
+}
+
+// CohereRerankResponse represents the response from a Cohere reranking request.
+// Cohere v2 does not echo the documents back, only their indices.
+type CohereRerankResponse struct {
+	ID      string `json:"id"`
+	Results []struct {
+		Index          int     `json:"index"`
+		RelevanceScore float64 `json:"relevance_score"`
+	} `json:"results"`
+}
+
+// NewCohereReranker creates a new instance of Cohere reranker with the provided configuration
+func NewCohereReranker(config *RerankerConfig) (*CohereReranker, error) {
+	baseURL := provider.CohereBaseURL
+	if url := config.BaseURL; url != "" {
+		baseURL = url
+	}
+
+	return &CohereReranker{
+		modelName: config.ModelName,
+		modelID:   config.ModelID,
+		apiKey:    config.APIKey,
+		baseURL:   baseURL,
+		client:    &http.Client{},
+	}, nil
+}
+
+// Rerank performs document reranking based on relevance to the query
+func (r *CohereReranker) Rerank(ctx context.Context, query string, documents []string) ([]RankResult, error) {
+	if len(documents) == 0 {
+		return nil, nil
+	}
+	requestBody := &CohereRerankRequest{
+		Model:     r.modelName,
+		Query:     query,
+		Documents: documents,
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request body: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/rerank", r.baseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.apiKey))
+	secutils.ApplyCustomHeaders(req, r.customHeaders)
+
+	logger.Debugf(ctx, "%s", buildRerankRequestDebug(r.modelName, url, query, documents))
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		logger.GetLogger(ctx).Errorf("CohereReranker API error: Http Status: %s, Body: %s", resp.Status, string(body))
+		return nil, fmt.Errorf("Rerank API error: Http Status: %s", resp.Status)
+	}
+
+	var response CohereRerankResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("unmarshal response: %w", err)
+	}
+	ret := make([]RankResult, 0, len(response.Results))
+	for _, result := range response.Results {
+		if result.Index < 0 || result.Index >= len(documents) {
+			continue
+		}
+		ret = append(ret, RankResult{
+			Index:          result.Index,
+			Document:       DocumentInfo{Text: documents[result.Index]},
+			RelevanceScore: result.RelevanceScore,
+		})
+	}
+	return ret, nil
+}
+
+// GetModelName returns the name of the reranking model
+func (r *CohereReranker) GetModelName() string {
+	return r.modelName
+}
+
+// GetModelID returns the unique identifier of the reranking model
+func (r *CohereReranker) GetModelID() string {
+	return r.modelID
+}
@@ -0,0 +1,55 @@
+package rerank
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCohereReranker_Rerank(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/rerank", r.URL.Path)
+		assert.Equal(t, "Bearer co-test", r.Header.Get("Authorization"))
+		var req CohereRerankRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, "rerank-v3.5", req.Model)
+		assert.Equal(t, "query", req.Query)
+		assert.Equal(t, []string{"a", "b"}, req.Documents)
+		_, _ = w.Write([]byte(`{"id":"x","results":[{"index":1,"relevance_score":0.9},{"index":0,"relevance_score":0.2},{"index":7,"relevance_score":0.1}]}`))
+	}))
+	defer srv.Close()
+
+	r, err := newReranker(&RerankerConfig{
+		APIKey:    "co-test",
+		BaseURL:   srv.URL,
+		ModelName: "rerank-v3.5",
+		Provider:  string(provider.ProviderCohere),
+	})
+	require.NoError(t, err)
+	require.IsType(t, &CohereReranker{}, r)
+
+	results, err := r.Rerank(t.Context(), "query", []string{"a", "b"})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, 1, results[0].Index)
+	assert.Equal(t, "b", results[0].Document.Text)
+	assert.InDelta(t, 0.9, results[0].RelevanceScore, 1e-9)
+	assert.Equal(t, "a", results[1].Document.Text)
+}
+
+func TestCohereReranker_Rerank_httpError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	r, err := NewCohereReranker(&RerankerConfig{BaseURL: srv.URL, ModelName: "rerank-v3.5"})
+	require.NoError(t, err)
+	_, err = r.Rerank(t.Context(), "query", []string{"a"})
+	require.Error(t, err)
+}
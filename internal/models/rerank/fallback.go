@@ -0,0 +1,54 @@
+package rerank
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+)
+
+// fallbackReranker tries its rerankers in order and returns the first
+// successful result, so an unavailable rerank backend does not cost the
+// pipeline its reranking.
+type fallbackReranker struct {
+	rerankers []Reranker
+}
+
+// NewFallbackReranker wraps primary so that the fallbacks are tried in
+// order when it fails. Without fallbacks primary is returned unchanged.
+// The name and ID reported are the primary's.
+func NewFallbackReranker(primary Reranker, fallbacks ...Reranker) Reranker {
+	if len(fallbacks) == 0 {
+		return primary
+	}
+	return &fallbackReranker{rerankers: append([]Reranker{primary}, fallbacks...)}
+}
+
+// Rerank implements Reranker
+func (f *fallbackReranker) Rerank(ctx context.Context, query string, documents []string) ([]RankResult, error) {
+	var err error
+	for i, r := range f.rerankers {
+		var results []RankResult
+		results, err = r.Rerank(ctx, query, documents)
+		if err == nil {
+			if i > 0 {
+				logger.Infof(ctx, "Reranked with fallback model %s", r.GetModelID())
+			}
+			return results, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		logger.Warnf(ctx, "Rerank model %s failed: %v", r.GetModelID(), err)
+	}
+	return nil, err
+}
+
+// GetModelName implements Reranker
+func (f *fallbackReranker) GetModelName() string {
+	return f.rerankers[0].GetModelName()
+}
+
+// GetModelID implements Reranker
+func (f *fallbackReranker) GetModelID() string {
+	return f.rerankers[0].GetModelID()
+}
@@ -0,0 +1,63 @@
+package rerank
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubReranker struct {
+	id    string
+	err   error
+	calls int
+}
+
+func (s *stubReranker) Rerank(ctx context.Context, query string, documents []string) ([]RankResult, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return []RankResult{{Index: 0, Document: DocumentInfo{Text: s.id}, RelevanceScore: 1}}, nil
+}
+
+func (s *stubReranker) GetModelName() string { return s.id }
+func (s *stubReranker) GetModelID() string   { return s.id }
+
+func TestNewFallbackReranker_withoutFallbacks(t *testing.T) {
+	primary := &stubReranker{id: "primary"}
+	assert.Same(t, primary, NewFallbackReranker(primary))
+}
+
+func TestFallbackReranker_usesFirstSuccess(t *testing.T) {
+	primary := &stubReranker{id: "primary", err: errors.New("down")}
+	second := &stubReranker{id: "second"}
+	third := &stubReranker{id: "third"}
+
+	r := NewFallbackReranker(primary, second, third)
+	results, err := r.Rerank(t.Context(), "q", []string{"doc"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "second", results[0].Document.Text)
+	assert.Equal(t, 0, third.calls)
+	assert.Equal(t, "primary", r.GetModelID())
+}
+
+func TestFallbackReranker_allFail(t *testing.T) {
+	last := errors.New("last")
+	r := NewFallbackReranker(&stubReranker{id: "a", err: errors.New("first")}, &stubReranker{id: "b", err: last})
+	_, err := r.Rerank(t.Context(), "q", []string{"doc"})
+	assert.ErrorIs(t, err, last)
+}
+
+func TestFallbackReranker_stopsOnCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	second := &stubReranker{id: "b"}
+	r := NewFallbackReranker(&stubReranker{id: "a", err: context.Canceled}, second)
+	_, err := r.Rerank(ctx, "q", []string{"doc"})
+	require.Error(t, err)
+	assert.Equal(t, 0, second.calls)
+}
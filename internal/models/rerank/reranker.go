@@ -7,6 +7,7 @@ import (
 
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/models/provider"
+	"github.com/Tencent/WeKnora/internal/tracing/telemetry"
 	"github.com/Tencent/WeKnora/internal/types"
 )
 
@@ -84,7 +85,7 @@ type RerankerConfig struct {
 	ModelName   string
 	Source      types.ModelSource
 	ModelID     string
-	Provider    string // Provider identifier: openai, aliyun, zhipu, siliconflow, jina, generic
+	Provider    string // Provider identifier: openai, aliyun, zhipu, siliconflow, jina, cohere, generic
 	ExtraConfig map[string]string
 	// CustomHeaders 允许在调用远程 API 时附加自定义 HTTP 请求头（类似 OpenAI Python SDK 的 extra_headers）。
 	CustomHeaders map[string]string
@@ -119,6 +120,9 @@ func NewReranker(config *RerankerConfig) (Reranker, error) {
 	if err != nil {
 		return r, err
 	}
+	if telemetry.Enabled() {
+		r = &tracingReranker{inner: r}
+	}
 	if logger.LLMDebugEnabled() {
 		r = &debugReranker{inner: r}
 	}
@@ -148,12 +152,16 @@ func newReranker(config *RerankerConfig) (Reranker, error) {
 		reranker, err = NewZhipuReranker(config)
 	case provider.ProviderJina:
 		reranker, err = NewJinaReranker(config)
+	case provider.ProviderCohere:
+		reranker, err = NewCohereReranker(config)
 	case provider.ProviderNvidia:
 		reranker, err = NewNvidiaReranker(config)
 	case provider.ProviderWeKnoraCloud:
 		reranker, err = NewWeKnoraCloudReranker(config)
 	case provider.ProviderLKEAP:
 		reranker, err = NewLKEAPReranker(config)
+	case provider.ProviderBedrock:
+		reranker, err = NewBedrockReranker(config)
 	default:
 		reranker, err = NewOpenAIReranker(config)
 	}
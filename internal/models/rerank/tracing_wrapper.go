@@ -0,0 +1,30 @@
+package rerank
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/tracing/telemetry"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// tracingReranker wraps a Reranker and records every call as an
+// OpenTelemetry span.
+type tracingReranker struct {
+	inner Reranker
+}
+
+func (t *tracingReranker) Rerank(ctx context.Context, query string, documents []string) ([]RankResult, error) {
+	model := t.inner.GetModelName()
+	ctx, span := telemetry.Start(ctx, "rerank "+model,
+		attribute.String("gen_ai.operation.name", "rerank"),
+		attribute.String("gen_ai.request.model", model),
+		attribute.Int("weknora.rerank.documents", len(documents)),
+	)
+	results, err := t.inner.Rerank(ctx, query, documents)
+	span.SetAttributes(attribute.Int("weknora.rerank.results", len(results)))
+	telemetry.End(span, err)
+	return results, err
+}
+
+func (t *tracingReranker) GetModelName() string { return t.inner.GetModelName() }
+func (t *tracingReranker) GetModelID() string   { return t.inner.GetModelID() }
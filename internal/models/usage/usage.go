@@ -0,0 +1,73 @@
+// Package usage 把模型调用的 token 用量交给进程内注册的 Recorder 记账。
+// chat 与 embedding 包在每次调用前后经由本包检查租户预算、上报用量；
+// 未注册 Recorder 时（如单元测试、命令行工具）两者都是空操作。
+package usage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Tencent/WeKnora/internal/metrics"
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// Recorder 记录模型调用的 token 用量，并决定租户是否还能继续调用模型
+type Recorder interface {
+	// CheckBudget 在调用模型前检查 ctx 所属租户的 token 预算，
+	// 用尽时返回包装了 types.ErrTokenBudgetExceeded 的错误
+	CheckBudget(ctx context.Context) error
+	// RecordUsage 记录一次模型调用的用量，租户与会话从 ctx 中获取
+	RecordUsage(ctx context.Context, event *types.TokenUsageEvent)
+}
+
+var (
+	mu       sync.RWMutex
+	recorder Recorder
+)
+
+// SetRecorder 注册进程内的用量记录器，传入 nil 表示取消注册
+func SetRecorder(r Recorder) {
+	mu.Lock()
+	defer mu.Unlock()
+	recorder = r
+}
+
+func getRecorder() Recorder {
+	mu.RLock()
+	defer mu.RUnlock()
+	return recorder
+}
+
+// CheckBudget 检查 ctx 所属租户的 token 预算，未注册记录器时总是放行
+func CheckBudget(ctx context.Context) error {
+	if r := getRecorder(); r != nil {
+		return r.CheckBudget(ctx)
+	}
+	return nil
+}
+
+// Record 上报一次模型调用的用量并计入 Prometheus token 指标；用量为空时忽略，
+// 未注册记录器时只计入指标
+func Record(ctx context.Context, event *types.TokenUsageEvent) {
+	if event == nil || event.PromptTokens+event.CompletionTokens+event.EmbeddingTokens == 0 {
+		return
+	}
+	metrics.RecordTokens(ctx, event)
+	if r := getRecorder(); r != nil {
+		r.RecordUsage(ctx, event)
+	}
+}
+
+// EstimateTokens 在供应商未返回用量时按文本长度估算 token 数：
+// 每段非空文本按 4 个字符约 1 个 token 计，另加 1 个 token。
+func EstimateTokens(texts ...string) int64 {
+	var total int64
+	for _, t := range texts {
+		runes := len([]rune(t))
+		if runes == 0 {
+			continue
+		}
+		total += int64(runes/4 + 1)
+	}
+	return total
+}
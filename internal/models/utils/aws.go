@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// AWSAuth 描述调用 AWS 服务（如 Amazon Bedrock）的凭证。
+// AccessKeyID 非空时按 SigV4 签名请求；否则把 APIKey 作为 Bearer token 发送（Bedrock API key）。
+type AWSAuth struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	APIKey          string
+	Service         string // 签名服务名，如 "bedrock"
+	Region          string
+}
+
+// Apply 为请求设置鉴权头。使用 SigV4 时会对所有已设置的请求头签名，
+// 因此需在设置完其余请求头（包括自定义请求头）之后调用。
+func (a AWSAuth) Apply(ctx context.Context, req *http.Request, body []byte) error {
+	if a.AccessKeyID == "" {
+		req.Header.Set("Authorization", "Bearer "+a.APIKey)
+		return nil
+	}
+
+	hash := sha256.Sum256(body)
+	creds := aws.Credentials{
+		AccessKeyID:     a.AccessKeyID,
+		SecretAccessKey: a.SecretAccessKey,
+		SessionToken:    a.SessionToken,
+	}
+	if err := v4.NewSigner().SignHTTP(ctx, creds, req, hex.EncodeToString(hash[:]),
+		a.Service, a.Region, time.Now()); err != nil {
+		return fmt.Errorf("sign request: %w", err)
+	}
+	return nil
+}
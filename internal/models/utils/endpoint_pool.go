@@ -0,0 +1,321 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	secutils "github.com/Tencent/WeKnora/internal/utils"
+)
+
+const (
+	// DefaultHealthCheckPath vLLM 与 llama.cpp server 在服务根路径下提供的健康检查地址
+	DefaultHealthCheckPath = "/health"
+	// endpointCheckInterval 同一副本两次健康检查的最小间隔
+	endpointCheckInterval = 15 * time.Second
+	// endpointCheckTimeout 单次健康检查（含模型信息探测）的超时
+	endpointCheckTimeout = 5 * time.Second
+)
+
+// endpointCheckClient 健康检查与模型信息探测使用的 HTTP client，
+// 与 LLM 调用一样在连接层做 SSRF 校验。
+var endpointCheckClient = &http.Client{
+	Timeout: endpointCheckTimeout,
+	Transport: &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		DialContext:         secutils.SSRFSafeDialContext,
+		TLSHandshakeTimeout: endpointCheckTimeout,
+		IdleConnTimeout:     90 * time.Second,
+		MaxIdleConnsPerHost: 2,
+	},
+}
+
+// EndpointPool 是同一模型的一组自托管 OpenAI 兼容副本（vLLM、llama.cpp server 等）。
+// 请求路由到在途请求最少的健康副本；健康状态由按需触发的后台健康检查和调用失败共同维护，
+// 健康检查顺带从 /models 探测模型的上下文长度。
+//
+// 每次获取模型都会重建客户端，因此 pool 按副本列表在进程内共享（见 GetEndpointPool），
+// 以便在途计数与健康状态跨请求生效。
+type EndpointPool struct {
+	endpoints  []*poolEndpoint
+	healthPath string
+	// next 用于在负载相同的副本之间轮转，避免总是命中第一个
+	next atomic.Uint64
+}
+
+type poolEndpoint struct {
+	baseURL  string
+	inflight atomic.Int64
+
+	mu        sync.Mutex
+	healthy   bool
+	checking  bool
+	checkedAt time.Time
+	// contextLengths 为该副本 /models 返回的各模型上下文长度
+	contextLengths map[string]int
+}
+
+var (
+	endpointPoolsMu sync.Mutex
+	endpointPools   = map[string]*EndpointPool{}
+)
+
+// GetEndpointPool 返回 endpoints 对应的共享 pool；副本列表与健康检查路径相同的模型共用同一个 pool。
+// endpoints 为各副本的 OpenAI 兼容 BaseURL（如 http://10.0.0.1:8000/v1），healthPath 为空时使用
+// DefaultHealthCheckPath。
+func GetEndpointPool(endpoints []string, healthPath string) *EndpointPool {
+	if healthPath == "" {
+		healthPath = DefaultHealthCheckPath
+	}
+	key := healthPath + "|" + strings.Join(endpoints, ",")
+
+	endpointPoolsMu.Lock()
+	defer endpointPoolsMu.Unlock()
+	if pool, ok := endpointPools[key]; ok {
+		return pool
+	}
+	pool := &EndpointPool{healthPath: healthPath}
+	for _, endpoint := range endpoints {
+		pool.endpoints = append(pool.endpoints, &poolEndpoint{baseURL: endpoint, healthy: true})
+	}
+	endpointPools[key] = pool
+	return pool
+}
+
+// Len 返回副本数量
+func (p *EndpointPool) Len() int {
+	return len(p.endpoints)
+}
+
+// BaseURL 返回第 i 个副本的 BaseURL
+func (p *EndpointPool) BaseURL(i int) string {
+	return p.endpoints[i].baseURL
+}
+
+// Acquire 选出不在 tried 中、在途请求最少的副本并为其计入一个在途请求，调用方须在请求结束后调用 release；
+// release 的 failed 为 true 时该副本被标记为不健康，直到下一次健康检查通过。
+// 健康副本都已尝试过时退而选择不健康的副本；所有副本都已尝试过时 ok 为 false。
+func (p *EndpointPool) Acquire(tried []int) (index int, release func(failed bool), ok bool) {
+	for _, ep := range p.endpoints {
+		ep.maybeCheck(p.healthPath)
+	}
+
+	index = -1
+	fallback := -1
+	start := int(p.next.Add(1) % uint64(len(p.endpoints)))
+	for n := range p.endpoints {
+		i := (start + n) % len(p.endpoints)
+		if slices.Contains(tried, i) {
+			continue
+		}
+		ep := p.endpoints[i]
+		if !ep.isHealthy() {
+			if fallback < 0 || ep.inflight.Load() < p.endpoints[fallback].inflight.Load() {
+				fallback = i
+			}
+			continue
+		}
+		if index < 0 || ep.inflight.Load() < p.endpoints[index].inflight.Load() {
+			index = i
+		}
+	}
+	if index < 0 {
+		index = fallback
+	}
+	if index < 0 {
+		return -1, nil, false
+	}
+
+	ep := p.endpoints[index]
+	ep.inflight.Add(1)
+	var once sync.Once
+	release = func(failed bool) {
+		once.Do(func() {
+			ep.inflight.Add(-1)
+			if failed {
+				ep.markDown()
+			}
+		})
+	}
+	return index, release, true
+}
+
+// ContextLength 返回副本上报的 model 上下文长度（各副本取最小值），无法获知时返回 0。
+// 尚无副本完成探测时会同步探测一次。
+func (p *EndpointPool) ContextLength(ctx context.Context, model string) int {
+	if length := p.knownContextLength(model); length > 0 {
+		return length
+	}
+	for _, ep := range p.endpoints {
+		if ep.check(ctx, p.healthPath) {
+			break
+		}
+	}
+	return p.knownContextLength(model)
+}
+
+func (p *EndpointPool) knownContextLength(model string) int {
+	length := 0
+	for _, ep := range p.endpoints {
+		ep.mu.Lock()
+		n := lookupContextLength(ep.contextLengths, model)
+		ep.mu.Unlock()
+		if n > 0 && (length == 0 || n < length) {
+			length = n
+		}
+	}
+	return length
+}
+
+// lookupContextLength 按模型名查找上下文长度；llama.cpp server 只加载一个模型，
+// 其 id 往往是模型文件路径，因此只有一个模型时直接使用。
+func lookupContextLength(lengths map[string]int, model string) int {
+	if n, ok := lengths[model]; ok {
+		return n
+	}
+	if len(lengths) == 1 {
+		for _, n := range lengths {
+			return n
+		}
+	}
+	return 0
+}
+
+func (ep *poolEndpoint) isHealthy() bool {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	return ep.healthy
+}
+
+func (ep *poolEndpoint) markDown() {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	ep.healthy = false
+	ep.checkedAt = time.Now()
+}
+
+// maybeCheck 距上次检查超过 endpointCheckInterval 时在后台触发一次健康检查
+func (ep *poolEndpoint) maybeCheck(healthPath string) {
+	ep.mu.Lock()
+	due := !ep.checking && time.Since(ep.checkedAt) >= endpointCheckInterval
+	if due {
+		ep.checking = true
+	}
+	ep.mu.Unlock()
+	if due {
+		go ep.runCheck(context.Background(), healthPath)
+	}
+}
+
+// check 同步检查副本，已有检查在进行时直接返回当前状态。返回副本是否健康。
+func (ep *poolEndpoint) check(ctx context.Context, healthPath string) bool {
+	ep.mu.Lock()
+	if ep.checking {
+		healthy := ep.healthy
+		ep.mu.Unlock()
+		return healthy
+	}
+	ep.checking = true
+	ep.mu.Unlock()
+	return ep.runCheck(ctx, healthPath)
+}
+
+// runCheck 请求副本的健康检查地址，健康时再从 /models 刷新上下文长度，返回副本是否健康。
+// 调用方须已将 checking 置为 true。
+func (ep *poolEndpoint) runCheck(ctx context.Context, healthPath string) bool {
+	ctx, cancel := context.WithTimeout(ctx, endpointCheckTimeout)
+	defer cancel()
+	root := serverRoot(ep.baseURL)
+	healthy := getJSON(ctx, root+healthPath, nil) == nil
+	var lengths map[string]int
+	if healthy {
+		lengths = fetchContextLengths(ctx, ep.baseURL, root)
+	}
+
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	ep.checking = false
+	ep.checkedAt = time.Now()
+	ep.healthy = healthy
+	if len(lengths) > 0 {
+		ep.contextLengths = lengths
+	}
+	return healthy
+}
+
+// modelsResponse 覆盖 vLLM（max_model_len）与 llama.cpp server（meta.n_ctx_train）两种 /models 返回
+type modelsResponse struct {
+	Data []struct {
+		ID          string `json:"id"`
+		MaxModelLen int    `json:"max_model_len"`
+		Meta        struct {
+			NCtxTrain int `json:"n_ctx_train"`
+		} `json:"meta"`
+	} `json:"data"`
+}
+
+// propsResponse 为 llama.cpp server 的 /props，n_ctx 为实际启动的上下文长度
+type propsResponse struct {
+	DefaultGenerationSettings struct {
+		NCtx int `json:"n_ctx"`
+	} `json:"default_generation_settings"`
+}
+
+// fetchContextLengths 探测副本各模型的上下文长度。llama.cpp server 的 /models 只有训练时的
+// 上下文长度，实际值以 /props 的 n_ctx 为准。
+func fetchContextLengths(ctx context.Context, baseURL, root string) map[string]int {
+	var models modelsResponse
+	if err := getJSON(ctx, baseURL+"/models", &models); err != nil {
+		return nil
+	}
+	lengths := make(map[string]int, len(models.Data))
+	var props *propsResponse
+	for _, model := range models.Data {
+		length := model.MaxModelLen
+		if length == 0 && model.Meta.NCtxTrain > 0 {
+			if props == nil {
+				props = &propsResponse{}
+				_ = getJSON(ctx, root+"/props", props)
+			}
+			length = props.DefaultGenerationSettings.NCtx
+			if length == 0 {
+				length = model.Meta.NCtxTrain
+			}
+		}
+		if length > 0 {
+			lengths[model.ID] = length
+		}
+	}
+	return lengths
+}
+
+// serverRoot 去掉 BaseURL 末尾的 /v1，得到健康检查等非 OpenAI 接口所在的服务根地址
+func serverRoot(baseURL string) string {
+	return strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/v1")
+}
+
+// getJSON 发送 GET 请求，out 非 nil 时解析 JSON 响应；非 2xx 状态视为失败
+func getJSON(ctx context.Context, url string, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := endpointCheckClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("GET %s failed with status %d", url, resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,91 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReplicaServer(t *testing.T, models string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.WriteHeader(http.StatusOK)
+		case "/v1/models":
+			_, _ = w.Write([]byte(models))
+		case "/props":
+			_, _ = w.Write([]byte(`{"default_generation_settings":{"n_ctx":16384}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestEndpointPool_LeastLoaded(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+	a := newReplicaServer(t, `{"data":[]}`)
+	b := newReplicaServer(t, `{"data":[]}`)
+	pool := GetEndpointPool([]string{a.URL + "/v1", b.URL + "/v1"}, "")
+	assert.Same(t, pool, GetEndpointPool([]string{a.URL + "/v1", b.URL + "/v1"}, DefaultHealthCheckPath))
+
+	first, releaseFirst, ok := pool.Acquire(nil)
+	require.True(t, ok)
+	second, releaseSecond, ok := pool.Acquire(nil)
+	require.True(t, ok)
+	assert.NotEqual(t, first, second, "the busy replica is skipped")
+
+	releaseSecond(false)
+	third, releaseThird, ok := pool.Acquire(nil)
+	require.True(t, ok)
+	assert.Equal(t, second, third)
+	releaseThird(false)
+	releaseFirst(false)
+
+	_, _, ok = pool.Acquire([]int{0, 1})
+	assert.False(t, ok, "every replica was tried")
+}
+
+func TestEndpointPool_FailedReplicaIsAvoided(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+	a := newReplicaServer(t, `{"data":[]}`)
+	b := newReplicaServer(t, `{"data":[]}`)
+	pool := GetEndpointPool([]string{a.URL + "/v1", b.URL + "/v1"}, "/health")
+	for _, ep := range pool.endpoints {
+		require.True(t, ep.check(context.Background(), pool.healthPath))
+	}
+
+	i, release, ok := pool.Acquire(nil)
+	require.True(t, ok)
+	release(true)
+	for range 4 {
+		j, release, ok := pool.Acquire(nil)
+		require.True(t, ok)
+		assert.NotEqual(t, i, j)
+		release(false)
+	}
+
+	j, release, ok := pool.Acquire([]int{1 - i})
+	require.True(t, ok)
+	assert.Equal(t, i, j, "an unhealthy replica is still used when nothing else is left")
+	release(false)
+}
+
+func TestEndpointPool_ContextLength(t *testing.T) {
+	t.Setenv("SSRF_WHITELIST", "127.0.0.1")
+
+	vllm := newReplicaServer(t, `{"data":[{"id":"qwen3-8b","max_model_len":32768},{"id":"other","max_model_len":4096}]}`)
+	pool := GetEndpointPool([]string{vllm.URL + "/v1"}, "")
+	assert.Equal(t, 32768, pool.ContextLength(context.Background(), "qwen3-8b"))
+	assert.Equal(t, 0, pool.ContextLength(context.Background(), "missing"))
+
+	llamaCpp := newReplicaServer(t, `{"data":[{"id":"/models/qwen3-8b-q4_k_m.gguf","meta":{"n_ctx_train":40960}}]}`)
+	pool = GetEndpointPool([]string{llamaCpp.URL + "/v1"}, "")
+	assert.Equal(t, 16384, pool.ContextLength(context.Background(), "qwen3-8b"))
+}
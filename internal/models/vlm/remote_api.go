@@ -53,17 +53,26 @@ func NewRemoteAPIVLM(config *Config) (*RemoteAPIVLM, error) {
 		providerName = provider.DetectProvider(config.BaseURL)
 	}
 
+	modelName := config.ModelName
 	var apiCfg openai.ClientConfig
 	if providerName == provider.ProviderAzureOpenAI {
-		apiCfg = openai.DefaultAzureConfig(config.APIKey, config.BaseURL)
-		apiCfg.AzureModelMapperFunc = func(model string) string {
-			return model
+		extra := make(map[string]string, len(config.Extra))
+		for k, v := range config.Extra {
+			if vs, ok := v.(string); ok {
+				extra[k] = vs
+			}
 		}
-		if config.Extra != nil {
-			if v, ok := config.Extra["api_version"]; ok {
-				if vs, ok := v.(string); ok && vs != "" {
-					apiCfg.APIVersion = vs
-				}
+		deployment := provider.AzureOpenAIDeployment(config.ModelName, extra)
+		apiVersion := provider.AzureOpenAIAPIVersion(extra)
+		if apiVersion == provider.AzureOpenAIV1APIVersion {
+			apiCfg = openai.DefaultConfig(config.APIKey)
+			apiCfg.BaseURL = provider.AzureOpenAIURL(config.BaseURL, "", apiVersion, "")
+			modelName = deployment
+		} else {
+			apiCfg = openai.DefaultAzureConfig(config.APIKey, provider.AzureOpenAIEndpoint(config.BaseURL))
+			apiCfg.APIVersion = apiVersion
+			apiCfg.AzureModelMapperFunc = func(string) string {
+				return deployment
 			}
 		}
 	} else {
@@ -82,7 +91,7 @@ func NewRemoteAPIVLM(config *Config) (*RemoteAPIVLM, error) {
 	}
 
 	return &RemoteAPIVLM{
-		modelName: config.ModelName,
+		modelName: modelName,
 		modelID:   config.ModelID,
 		client:    openai.NewClientWithConfig(apiCfg),
 		baseURL:   config.BaseURL,
@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,7 +16,9 @@ import (
 const localCleanupInterval = time.Minute
 
 // rateLimitScript atomically prunes expired ZSET members, checks the count,
-// and conditionally records a new hit.
+// and conditionally records a new hit. It returns {1, 0} when the hit is
+// recorded and {0, retryMs} otherwise, retryMs being the time until the
+// oldest hit leaves the window.
 var rateLimitScript = redis.NewScript(`
 local key     = KEYS[1]
 local now     = tonumber(ARGV[1])
@@ -28,9 +31,14 @@ local count = redis.call('ZCARD', key)
 if count < maxReq then
     redis.call('ZADD', key, now, member)
     redis.call('PEXPIRE', key, window + 1000)
-    return 1
+    return {1, 0}
 end
-return 0
+local oldest = redis.call('ZRANGE', key, 0, 0, 'WITHSCORES')
+local retry = window
+if oldest[2] then
+    retry = tonumber(oldest[2]) + window - now
+end
+return {0, retry}
 `)
 
 // Limiter enforces per-key sliding-window limits. max is evaluated per Allow
@@ -42,6 +50,9 @@ type Limiter struct {
 	keyPrefix  string
 	window     time.Duration
 	instanceID string
+	// seq keeps ZSET members unique when one instance records several hits
+	// for the same key within a millisecond.
+	seq atomic.Uint64
 }
 
 // New constructs a limiter. keyPrefix should include a trailing delimiter
@@ -64,32 +75,42 @@ func New(redisClient *redis.Client, keyPrefix string, window time.Duration, inst
 
 // Allow reports whether key is within budget for the current window.
 func (l *Limiter) Allow(ctx context.Context, key string, max int) bool {
+	allowed, _ := l.Check(ctx, key, max)
+	return allowed
+}
+
+// Check is Allow that also reports, when the hit is denied, how long the
+// caller should wait before the next hit can fit in the window.
+func (l *Limiter) Check(ctx context.Context, key string, max int) (bool, time.Duration) {
 	if max <= 0 {
-		return true
+		return true, 0
 	}
 	if l.redis != nil {
-		allowed, err := l.redisAllow(ctx, key, max)
+		allowed, retryAfter, err := l.redisCheck(ctx, key, max)
 		if err == nil {
-			return allowed
+			return allowed, retryAfter
 		}
 	}
 	return l.local.allow(key, l.window, max)
 }
 
-func (l *Limiter) redisAllow(ctx context.Context, key string, max int) (bool, error) {
+func (l *Limiter) redisCheck(ctx context.Context, key string, max int) (bool, time.Duration, error) {
 	redisKey := l.keyPrefix + key
 	nowMs := time.Now().UnixMilli()
 	windowMs := l.window.Milliseconds()
-	member := fmt.Sprintf("%s:%d", l.instanceID, nowMs)
+	member := fmt.Sprintf("%s:%d:%d", l.instanceID, nowMs, l.seq.Add(1))
 
 	result, err := rateLimitScript.Run(ctx, l.redis,
 		[]string{redisKey},
 		nowMs, windowMs, max, member,
-	).Int64()
+	).Int64Slice()
 	if err != nil {
-		return false, err
+		return false, 0, err
+	}
+	if len(result) != 2 {
+		return false, 0, fmt.Errorf("unexpected rate limit script result: %v", result)
 	}
-	return result == 1, nil
+	return result[0] == 1, time.Duration(result[1]) * time.Millisecond, nil
 }
 
 // StartCleanup runs periodic eviction for the local fallback map. No-op when
@@ -112,7 +133,7 @@ func newLocalLimiter() *localLimiter {
 	return &localLimiter{}
 }
 
-func (l *localLimiter) allow(key string, window time.Duration, max int) bool {
+func (l *localLimiter) allow(key string, window time.Duration, max int) (bool, time.Duration) {
 	now := time.Now()
 	cutoff := now.Add(-window)
 
@@ -136,12 +157,13 @@ func (l *localLimiter) allow(key string, window time.Duration, max int) bool {
 		entry.timestamps = valid
 
 		if len(entry.timestamps) >= max {
+			retryAfter := entry.timestamps[0].Add(window).Sub(now)
 			entry.mu.Unlock()
-			return false
+			return false, retryAfter
 		}
 		entry.timestamps = append(entry.timestamps, now)
 		entry.mu.Unlock()
-		return true
+		return true, 0
 	}
 }
 
@@ -39,3 +39,18 @@ func TestAllowSkipsWhenMaxZero(t *testing.T) {
 		}
 	}
 }
+
+func TestCheckReportsRetryAfter(t *testing.T) {
+	l := New(nil, "test:", time.Minute, "inst")
+	ctx := context.Background()
+	if ok, retryAfter := l.Check(ctx, "k", 1); !ok || retryAfter != 0 {
+		t.Fatalf("first request should be allowed without retry, got %v %v", ok, retryAfter)
+	}
+	ok, retryAfter := l.Check(ctx, "k", 1)
+	if ok {
+		t.Fatal("request over budget should be denied")
+	}
+	if retryAfter <= 0 || retryAfter > time.Minute {
+		t.Fatalf("retry after should fall within the window, got %v", retryAfter)
+	}
+}
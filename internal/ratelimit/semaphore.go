@@ -0,0 +1,129 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireScript atomically drops expired leases, checks the number of live
+// leases and conditionally adds a new one scored by its expiry time.
+var acquireScript = redis.NewScript(`
+local key     = KEYS[1]
+local now     = tonumber(ARGV[1])
+local lease   = tonumber(ARGV[2])
+local maxHeld = tonumber(ARGV[3])
+local member  = ARGV[4]
+
+redis.call('ZREMRANGEBYSCORE', key, 0, now)
+local count = redis.call('ZCARD', key)
+if count < maxHeld then
+    redis.call('ZADD', key, now + lease, member)
+    redis.call('PEXPIRE', key, lease + 1000)
+    return 1
+end
+return 0
+`)
+
+// Semaphore bounds the number of concurrent holders per key (e.g. chats in
+// flight per tenant) across instances. Every slot is a lease: a holder that
+// dies without releasing (crashed instance) frees its slot once the lease
+// expires, so lease should exceed the longest expected hold.
+type Semaphore struct {
+	redis      *redis.Client
+	local      *localSemaphore
+	keyPrefix  string
+	lease      time.Duration
+	instanceID string
+	seq        atomic.Uint64
+}
+
+// NewSemaphore constructs a semaphore. keyPrefix should include a trailing
+// delimiter (e.g. "quota:chats:"). When redis is nil, only the local
+// fallback runs.
+func NewSemaphore(redisClient *redis.Client, keyPrefix string, lease time.Duration, instanceID string) *Semaphore {
+	if lease <= 0 {
+		lease = 30 * time.Minute
+	}
+	if instanceID == "" {
+		instanceID = uuid.New().String()
+	}
+	return &Semaphore{
+		redis:      redisClient,
+		local:      &localSemaphore{held: make(map[string]int)},
+		keyPrefix:  keyPrefix,
+		lease:      lease,
+		instanceID: instanceID,
+	}
+}
+
+// Acquire takes a slot for key when fewer than max are held. On success the
+// returned release func frees the slot; it is safe to call more than once.
+// max <= 0 means no limit.
+func (s *Semaphore) Acquire(ctx context.Context, key string, max int) (release func(), ok bool) {
+	if max <= 0 {
+		return func() {}, true
+	}
+	if s.redis != nil {
+		member := fmt.Sprintf("%s:%d", s.instanceID, s.seq.Add(1))
+		acquired, err := s.redisAcquire(ctx, key, max, member)
+		if err == nil {
+			if !acquired {
+				return nil, false
+			}
+			var once sync.Once
+			return func() {
+				once.Do(func() {
+					// Release even when the request context is already done.
+					s.redis.ZRem(context.Background(), s.keyPrefix+key, member)
+				})
+			}, true
+		}
+	}
+	if !s.local.acquire(key, max) {
+		return nil, false
+	}
+	var once sync.Once
+	return func() { once.Do(func() { s.local.release(key) }) }, true
+}
+
+func (s *Semaphore) redisAcquire(ctx context.Context, key string, max int, member string) (bool, error) {
+	result, err := acquireScript.Run(ctx, s.redis,
+		[]string{s.keyPrefix + key},
+		time.Now().UnixMilli(), s.lease.Milliseconds(), max, member,
+	).Int64()
+	if err != nil {
+		return false, err
+	}
+	return result == 1, nil
+}
+
+type localSemaphore struct {
+	mu   sync.Mutex
+	held map[string]int
+}
+
+func (l *localSemaphore) acquire(key string, max int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[key] >= max {
+		return false
+	}
+	l.held[key]++
+	return true
+}
+
+func (l *localSemaphore) release(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[key] <= 1 {
+		delete(l.held, key)
+		return
+	}
+	l.held[key]--
+}
@@ -0,0 +1,42 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLocalSemaphoreBoundsHolders(t *testing.T) {
+	s := NewSemaphore(nil, "test:", time.Minute, "inst")
+	ctx := context.Background()
+	release, ok := s.Acquire(ctx, "k", 1)
+	if !ok {
+		t.Fatal("first holder should acquire")
+	}
+	if _, ok := s.Acquire(ctx, "k", 1); ok {
+		t.Fatal("second holder should be denied")
+	}
+	if _, ok := s.Acquire(ctx, "other", 1); !ok {
+		t.Fatal("other keys should not share slots")
+	}
+
+	release()
+	release() // releasing twice must not free a second slot
+	if _, ok := s.Acquire(ctx, "k", 1); !ok {
+		t.Fatal("released slot should be acquirable")
+	}
+	if _, ok := s.Acquire(ctx, "k", 1); ok {
+		t.Fatal("double release should not free an extra slot")
+	}
+}
+
+func TestSemaphoreSkipsWhenMaxZero(t *testing.T) {
+	s := NewSemaphore(nil, "test:", time.Minute, "inst")
+	for i := 0; i < 10; i++ {
+		release, ok := s.Acquire(context.Background(), "k", 0)
+		if !ok {
+			t.Fatal("max<=0 should always acquire")
+		}
+		release()
+	}
+}
@@ -2,6 +2,8 @@ package router
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -22,8 +24,10 @@ import (
 	"github.com/Tencent/WeKnora/internal/handler"
 	"github.com/Tencent/WeKnora/internal/handler/session"
 	"github.com/Tencent/WeKnora/internal/logger"
+	"github.com/Tencent/WeKnora/internal/metrics"
 	"github.com/Tencent/WeKnora/internal/middleware"
 	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/tracing/telemetry"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	secutils "github.com/Tencent/WeKnora/internal/utils"
@@ -52,6 +56,9 @@ type RouterParams struct {
 	TenantHandler                *handler.TenantHandler
 	TenantService                interfaces.TenantService
 	TenantMemberService          interfaces.TenantMemberService
+	APIKeyService                interfaces.APIKeyService
+	APIKeyHandler                *handler.APIKeyHandler
+	RetentionHandler             *handler.RetentionHandler
 	TenantMemberHandler          *handler.TenantMemberHandler
 	TenantInvitationHandler      *handler.TenantInvitationHandler
 	AuditLogHandler              *handler.AuditLogHandler
@@ -59,23 +66,38 @@ type RouterParams struct {
 	ChunkHandler                 *handler.ChunkHandler
 	SessionHandler               *session.Handler
 	MessageHandler               *handler.MessageHandler
+	FeedbackHandler              *handler.FeedbackHandler
 	ModelHandler                 *handler.ModelHandler
 	ModelCredentialsHandler      *handler.ModelCredentialsHandler
 	EvaluationHandler            *handler.EvaluationHandler
+	RetrievalEvalHandler         *handler.RetrievalEvalHandler
+	ExperimentHandler            *handler.ExperimentHandler
+	AnalyticsHandler             *handler.AnalyticsHandler
+	KnowledgeGapHandler          *handler.KnowledgeGapHandler
 	AuthHandler                  *handler.AuthHandler
 	InitializationHandler        *handler.InitializationHandler
 	SystemHandler                *handler.SystemHandler
+	HealthHandler                *handler.HealthHandler
 	MCPServiceHandler            *handler.MCPServiceHandler
 	MCPCredentialsHandler        *handler.MCPCredentialsHandler
 	MCPOAuthHandler              *handler.MCPOAuthHandler
 	WebSearchHandler             *handler.WebSearchHandler
 	WebSearchProviderHandler     *handler.WebSearchProviderHandler
 	WebSearchCredentialsHandler  *handler.WebSearchProviderCredentialsHandler
+	SQLConnectionHandler         *handler.SQLConnectionHandler
+	TokenUsageHandler            *handler.TokenUsageHandler
 	VectorStoreHandler           *handler.VectorStoreHandler
 	FAQHandler                   *handler.FAQHandler
 	TagHandler                   *handler.TagHandler
+	GlossaryHandler              *handler.GlossaryHandler
+	GraphCommunityHandler        *handler.GraphCommunityHandler
+	DuplicateHandler             *handler.DuplicateHandler
+	AutoTagHandler               *handler.AutoTagHandler
+	TagAccessHandler             *handler.TagAccessHandler
+	IndexMigrationHandler        *handler.IndexMigrationHandler
 	CustomAgentHandler           *handler.CustomAgentHandler
 	UserFavoriteHandler          *handler.UserResourceFavoriteHandler
+	MemoryHandler                *handler.MemoryHandler
 	SkillHandler                 *handler.SkillHandler
 	OrganizationHandler          *handler.OrganizationHandler
 	IMHandler                    *handler.IMHandler
@@ -115,6 +137,8 @@ func NewRouter(params RouterParams) *gin.Engine {
 
 	// 基础中间件（不需要认证）
 	r.Use(middleware.RequestID())
+	// OpenTelemetry 链路追踪：未配置 OTLP 导出端点时直接放行
+	r.Use(telemetry.GinMiddleware())
 	r.Use(middleware.Language())
 	r.Use(middleware.Logger())
 	r.Use(middleware.Recovery())
@@ -125,6 +149,11 @@ func NewRouter(params RouterParams) *gin.Engine {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// Prometheus 指标（不需要认证；设置 METRICS_TOKEN 后需携带 Bearer token）
+	if metrics.Enabled() {
+		r.GET("/metrics", gin.WrapH(metrics.Handler()))
+	}
+
 	// Swagger API 文档（仅在非生产环境下启用）
 	// 通过 GIN_MODE 环境变量判断：release 模式下禁用 Swagger
 	if gin.Mode() != gin.ReleaseMode {
@@ -153,11 +182,16 @@ func NewRouter(params RouterParams) *gin.Engine {
 	// IM 回调路由（在认证中间件之前注册，使用各平台自身的签名验证）
 	RegisterIMRoutes(r, params.IMHandler)
 
+	// 数据源 Webhook 回调（在认证中间件之前注册，由连接器校验签名）
+	RegisterDataSourceWebhookRoutes(r, params.DataSourceHandler)
+
 	// Web embed 公开路由（使用 publish token 鉴权，不走全局 Auth）
 	RegisterEmbedPublicRoutes(r, params.EmbedChannelHandler, params.EmbedChannelService, params.TenantService, params.RedisClient, params.FileService)
 
 	// 认证中间件
-	r.Use(middleware.Auth(params.TenantService, params.UserService, params.TenantMemberService, params.Config))
+	r.Use(middleware.Auth(
+		params.TenantService, params.UserService, params.TenantMemberService, params.APIKeyService, params.Config,
+	))
 
 	// 文件服务：统一代理本地/MinIO/COS/TOS存储后端（需要认证）
 	serveFiles(r, params.FileService)
@@ -201,28 +235,52 @@ func NewRouter(params RouterParams) *gin.Engine {
 			params.AgentShareService,
 		)
 
+		// Per-tenant API limits (config `rate_limit:`). The request rate
+		// applies to every authenticated route; chat concurrency and
+		// daily uploads are attached to their routes below.
+		quotas := middleware.NewTenantQuotas(params.Config, params.RedisClient)
+		v1.Use(quotas.RequestRate())
+
 		RegisterAuthRoutes(v1, params.AuthHandler)
 		RegisterTenantRoutes(v1, params.TenantHandler, params.TenantMemberHandler, params.TenantInvitationHandler, params.AuditLogHandler, rbacGuards)
 		RegisterMyInvitationRoutes(v1, params.TenantInvitationHandler)
 		RegisterKnowledgeBaseRoutes(v1, params.KBHandler, rbacGuards)
 		RegisterKnowledgeTagRoutes(v1, params.TagHandler, rbacGuards)
-		RegisterKnowledgeRoutes(v1, params.KnowledgeHandler, rbacGuards)
+		RegisterGlossaryRoutes(v1, params.GlossaryHandler, rbacGuards)
+		RegisterGraphCommunityRoutes(v1, params.GraphCommunityHandler, rbacGuards)
+		RegisterDuplicateRoutes(v1, params.DuplicateHandler, rbacGuards)
+		RegisterAutoTagRoutes(v1, params.AutoTagHandler, rbacGuards)
+		RegisterTagAccessRoutes(v1, params.TagAccessHandler, rbacGuards)
+		RegisterAPIKeyRoutes(v1, params.APIKeyHandler, rbacGuards)
+		RegisterRetentionRoutes(v1, params.RetentionHandler, rbacGuards)
+		RegisterIndexMigrationRoutes(v1, params.IndexMigrationHandler, rbacGuards)
+		RegisterKnowledgeRoutes(v1, params.KnowledgeHandler, rbacGuards, quotas)
 		RegisterFAQRoutes(v1, params.FAQHandler, rbacGuards)
 		RegisterChunkRoutes(v1, params.ChunkHandler, rbacGuards)
 		RegisterSessionRoutes(v1, params.SessionHandler, rbacGuards)
-		RegisterChatRoutes(v1, params.SessionHandler, rbacGuards)
+		RegisterChatRoutes(v1, params.SessionHandler, rbacGuards, quotas)
+		RegisterChatCompletionRoutes(v1, params.ModelHandler, rbacGuards, quotas)
 		RegisterMessageRoutes(v1, params.MessageHandler, rbacGuards)
+		RegisterFeedbackRoutes(v1, params.FeedbackHandler, rbacGuards)
 		RegisterModelRoutes(v1, params.ModelHandler, params.ModelCredentialsHandler, rbacGuards)
 		RegisterEvaluationRoutes(v1, params.EvaluationHandler, rbacGuards)
+		RegisterRetrievalEvalRoutes(v1, params.RetrievalEvalHandler, rbacGuards)
+		RegisterExperimentRoutes(v1, params.ExperimentHandler, rbacGuards)
+		RegisterAnalyticsRoutes(v1, params.AnalyticsHandler, rbacGuards)
+		RegisterKnowledgeGapRoutes(v1, params.KnowledgeGapHandler, rbacGuards)
 		RegisterInitializationRoutes(v1, params.InitializationHandler, rbacGuards)
 		RegisterSystemRoutes(v1, params.SystemHandler, rbacGuards)
+		RegisterHealthRoutes(v1, params.HealthHandler, rbacGuards)
 		RegisterSystemAdminRoutes(v1, params.SystemHandler, params.AuditLogHandler, rbacGuards)
 		RegisterMCPServiceRoutes(v1, params.MCPServiceHandler, params.MCPCredentialsHandler, params.MCPOAuthHandler, rbacGuards)
 		RegisterWebSearchRoutes(v1, params.WebSearchHandler, rbacGuards)
 		RegisterWebSearchProviderRoutes(v1, params.WebSearchProviderHandler, params.WebSearchCredentialsHandler, rbacGuards)
+		RegisterSQLConnectionRoutes(v1, params.SQLConnectionHandler, rbacGuards)
+		RegisterTokenUsageRoutes(v1, params.TokenUsageHandler, rbacGuards)
 		RegisterVectorStoreRoutes(v1, params.VectorStoreHandler, rbacGuards)
 		RegisterCustomAgentRoutes(v1, params.CustomAgentHandler, rbacGuards)
 		RegisterUserFavoriteRoutes(v1, params.UserFavoriteHandler, rbacGuards)
+		RegisterMemoryRoutes(v1, params.MemoryHandler, rbacGuards)
 		RegisterSkillRoutes(v1, params.SkillHandler, rbacGuards)
 		RegisterOrganizationRoutes(v1, params.OrganizationHandler, rbacGuards)
 		RegisterIMChannelRoutes(v1, params.IMHandler, rbacGuards)
@@ -286,13 +344,17 @@ func RegisterChunkRoutes(r *gin.RouterGroup, handler *handler.ChunkHandler, g *r
 // reuse OwnedKBOrAdmin because the URL :id is the KB id directly.
 // Cross-:id batch operations stay Contributor-gated — they don't have
 // a single owning KB to check against.
-func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandler, g *rbacGuards) {
+func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandler, g *rbacGuards, quotas *middleware.TenantQuotas) {
 	// 知识库下的知识路由组（URL :id is the KB id）
 	kb := r.Group("/knowledge-bases/:id/knowledge")
 	{
-		kb.POST("/file", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), handler.CreateKnowledgeFromFile)
-		kb.POST("/url", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), handler.CreateKnowledgeFromURL)
-		kb.POST("/manual", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), handler.CreateManualKnowledge)
+		// 新增文档计入每日上传限额并预先检查存储配额
+		kb.POST("/file", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), quotas.Uploads(), handler.CreateKnowledgeFromFile)
+		kb.POST("/url", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), quotas.Uploads(), handler.CreateKnowledgeFromURL)
+		kb.POST("/manual", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), quotas.Uploads(), handler.CreateManualKnowledge)
+		kb.POST("/code-archive", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), quotas.Uploads(), handler.CreateKnowledgeFromCodeArchive)
+		kb.POST("/chunks-import", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), quotas.Uploads(), handler.ImportChunks)
+		kb.GET("/chunks-import/:task_id", g.Viewer(), g.KBAccessRead("id"), handler.GetChunkImportProgress)
 		kb.GET("", g.Viewer(), g.KBAccessRead("id"), handler.ListKnowledge)
 		// Clearing all contents under a KB is a destructive op; gate
 		// behind Admin instead of Contributor.
@@ -305,18 +367,27 @@ func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandl
 		// Cross-knowledge endpoints (no :id) can't be gated on a single
 		// KB — they accept arbitrary knowledge IDs and the handler must
 		// fan out the access check itself. So /batch and /search keep
-		// the role-only floor; /move and /batch-delete stay Contributor.
+		// the role-only floor; /move, /merge and /batch-delete stay Contributor.
 		k.GET("/batch", g.Viewer(), handler.GetKnowledgeBatch)
 		k.GET("/:id", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.GetKnowledge)
 		k.GET("/:id/stages", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.GetKnowledgeSpans)
 		k.GET("/:id/spans", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.GetKnowledgeSpans)
+		k.GET("/:id/job", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.GetKnowledgeJob)
+		k.GET("/:id/job/stream", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.StreamKnowledgeJob)
 		k.DELETE("/:id", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.DeleteKnowledge)
 		k.PUT("/:id", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.UpdateKnowledge)
 		k.PUT("/manual/:id", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.UpdateManualKnowledge)
+		// 文档 ACL 决定谁能在检索中读到该文档，仅管理员可设置
+		k.PUT("/:id/acl", g.Admin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.UpdateKnowledgeACL)
 		k.POST("/:id/reparse", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.ReparseKnowledge)
+		k.PUT("/:id/file", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.UpdateKnowledgeFile)
+		k.GET("/:id/versions", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.ListKnowledgeVersions)
 		k.POST("/:id/cancel-parse", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.CancelKnowledgeParse)
+		k.POST("/:id/questions/regenerate", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.RegenerateQuestions)
 		k.GET("/:id/download", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.DownloadKnowledgeFile)
 		k.GET("/:id/preview", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.PreviewKnowledgeFile)
+		k.GET("/:id/preview-url", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.GetKnowledgePreviewURL)
+		k.GET("/:id/thumbnail", g.Viewer(), g.KBAccessReadFromKnowledgeIDParam("id"), handler.GetKnowledgeThumbnail)
 		k.PUT("/image/:id/:chunk_id", g.OwnedKnowledgeKBOrAdmin(), g.KBAccessWriteFromKnowledgeIDParam("id"), handler.UpdateImageInfo)
 		// Batch / cross-KB ops stay Contributor-gated: there is no
 		// single owning KB to walk back to. A future PR could add a
@@ -328,6 +399,7 @@ func RegisterKnowledgeRoutes(r *gin.RouterGroup, handler *handler.KnowledgeHandl
 		k.POST("/batch-delete", g.Contributor(), handler.BatchDeleteKnowledge)
 		k.POST("/move", g.Contributor(), handler.MoveKnowledge)
 		k.GET("/move/progress/:task_id", g.Viewer(), handler.GetKnowledgeMoveProgress)
+		k.POST("/merge", g.Contributor(), handler.MergeKnowledgeBase)
 	}
 }
 
@@ -404,6 +476,26 @@ func RegisterKnowledgeBaseRoutes(r *gin.RouterGroup, handler *handler.KnowledgeB
 		kb.GET("/copy/progress/:task_id", g.Viewer(), handler.GetKBCloneProgress)
 		// 获取可移动目标知识库列表 — Viewer+ 且对 KB 有 read 权限
 		kb.GET("/:id/move-targets", g.Viewer(), g.KBAccessRead("id"), handler.ListMoveTargets)
+		// 使用当前加密密钥重写知识库文件 — 创建者本人 OR Admin+ 且对 KB 有 write 权限
+		kb.POST("/:id/reencrypt-files", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), handler.ReencryptKnowledgeBaseFiles)
+		kb.POST("/:id/migrate-files", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), handler.MigrateKnowledgeBaseFiles)
+		// 创建知识库快照 — 创建者本人 OR Admin+ 且对 KB 有 write 权限
+		kb.POST("/:id/snapshots", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), handler.CreateKBSnapshot)
+		// 获取知识库快照列表 — Viewer+ 且对 KB 有 read 权限
+		kb.GET("/:id/snapshots", g.Viewer(), g.KBAccessRead("id"), handler.ListKBSnapshots)
+		// 获取/更新定时快照计划
+		kb.GET("/:id/snapshot-policy", g.Viewer(), g.KBAccessRead("id"), handler.GetKBSnapshotPolicy)
+		kb.PUT("/:id/snapshot-policy", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), handler.UpdateKBSnapshotPolicy)
+	}
+
+	// 知识库快照路由组 — 快照在知识库删除后仍保留，按租户管理，Admin+
+	snapshots := r.Group("/kb-snapshots")
+	{
+		snapshots.GET("", g.Admin(), handler.ListTenantKBSnapshots)
+		snapshots.GET("/:id", g.Admin(), handler.GetKBSnapshot)
+		snapshots.DELETE("/:id", g.Admin(), handler.DeleteKBSnapshot)
+		// 从快照恢复为新知识库，进度通过 /knowledge-bases/copy/progress/:task_id 查询
+		snapshots.POST("/:id/restore", g.Admin(), handler.RestoreKBSnapshot)
 	}
 }
 
@@ -432,6 +524,159 @@ func RegisterKnowledgeTagRoutes(r *gin.RouterGroup, tagHandler *handler.TagHandl
 	}
 }
 
+// RegisterGlossaryRoutes 注册知识库术语表相关路由。
+//
+// The glossary is derived KB content: readers of the KB may list it,
+// rebuilding it follows the same owner-or-admin rule as tag edits.
+func RegisterGlossaryRoutes(r *gin.RouterGroup, glossaryHandler *handler.GlossaryHandler, g *rbacGuards) {
+	if glossaryHandler == nil {
+		return
+	}
+	glossary := r.Group("/knowledge-bases/:id/glossary")
+	{
+		// 获取术语表
+		glossary.GET("", g.Viewer(), g.KBAccessRead("id"), glossaryHandler.ListGlossary)
+		// 异步重建术语表
+		glossary.POST("/build", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), glossaryHandler.BuildGlossary)
+	}
+}
+
+// RegisterGraphCommunityRoutes 注册知识图谱社区报告相关路由。
+//
+// Community reports are derived KB content like the glossary and share its
+// access rules.
+func RegisterGraphCommunityRoutes(
+	r *gin.RouterGroup, graphCommunityHandler *handler.GraphCommunityHandler, g *rbacGuards,
+) {
+	if graphCommunityHandler == nil {
+		return
+	}
+	communities := r.Group("/knowledge-bases/:id/graph/communities")
+	{
+		// 获取社区报告
+		communities.GET("", g.Viewer(), g.KBAccessRead("id"), graphCommunityHandler.ListCommunities)
+		// 异步重建社区报告
+		communities.POST("/build", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), graphCommunityHandler.BuildCommunities)
+	}
+}
+
+// RegisterDuplicateRoutes 注册知识库重复内容检测相关路由。
+//
+// Readers of the KB may see the report; analyzing and removing duplicates
+// changes KB content and follows the owner-or-admin rule of tag edits.
+func RegisterDuplicateRoutes(r *gin.RouterGroup, duplicateHandler *handler.DuplicateHandler, g *rbacGuards) {
+	if duplicateHandler == nil {
+		return
+	}
+	duplicates := r.Group("/knowledge-bases/:id/duplicates")
+	{
+		// 获取最近一次重复内容分析报告
+		duplicates.GET("", g.Viewer(), g.KBAccessRead("id"), duplicateHandler.GetDuplicateReport)
+		// 异步分析重复内容
+		duplicates.POST("/analyze", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), duplicateHandler.AnalyzeDuplicates)
+		// 禁用或删除报告中的冗余内容
+		duplicates.POST("/remove", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), duplicateHandler.RemoveDuplicates)
+	}
+}
+
+// RegisterAutoTagRoutes 注册文档自动打标签相关路由。
+//
+// Re-tagging rewrites document tags and follows the owner-or-admin rule of
+// tag edits.
+func RegisterAutoTagRoutes(r *gin.RouterGroup, autoTagHandler *handler.AutoTagHandler, g *rbacGuards) {
+	if autoTagHandler == nil {
+		return
+	}
+	// 按标签体系重新为文档打标签
+	r.POST("/knowledge-bases/:id/auto-tag", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), autoTagHandler.RetagKnowledgeBase)
+}
+
+// RegisterTagAccessRoutes 注册标签访问规则相关路由。
+//
+// Rules decide what other tenant members may retrieve, so managing them is
+// an Admin operation rather than a KB-owner one.
+func RegisterTagAccessRoutes(r *gin.RouterGroup, tagAccessHandler *handler.TagAccessHandler, g *rbacGuards) {
+	if tagAccessHandler == nil {
+		return
+	}
+	rules := r.Group("/knowledge-bases/:id/tag-access-rules")
+	{
+		// 获取知识库的标签访问规则
+		rules.GET("", g.Admin(), g.KBAccessRead("id"), tagAccessHandler.ListTagAccessRules)
+		// 创建或替换主体的标签访问规则
+		rules.PUT("", g.Admin(), g.KBAccessWrite("id"), tagAccessHandler.SaveTagAccessRule)
+		// 删除标签访问规则
+		rules.DELETE("/:rule_id", g.Admin(), g.KBAccessWrite("id"), tagAccessHandler.DeleteTagAccessRule)
+	}
+}
+
+// RegisterAPIKeyRoutes 注册 API Key 管理相关路由。
+//
+// Keys act for the whole tenant, so issuing and revoking them is an Owner
+// operation like rotating the legacy tenant key. Keys cap at Admin, so a key
+// can never manage keys itself.
+func RegisterAPIKeyRoutes(r *gin.RouterGroup, apiKeyHandler *handler.APIKeyHandler, g *rbacGuards) {
+	if apiKeyHandler == nil {
+		return
+	}
+	keys := r.Group("/api-keys")
+	{
+		// 获取 API Key 列表
+		keys.GET("", g.Owner(), apiKeyHandler.ListAPIKeys)
+		// 创建 API Key
+		keys.POST("", g.Owner(), apiKeyHandler.CreateAPIKey)
+		// 更新 API Key 设置
+		keys.PUT("/:id", g.Owner(), apiKeyHandler.UpdateAPIKey)
+		// 轮换 API Key 密钥
+		keys.POST("/:id/rotate", g.Owner(), apiKeyHandler.RotateAPIKey)
+		// 吊销 API Key
+		keys.DELETE("/:id", g.Owner(), apiKeyHandler.RevokeAPIKey)
+	}
+}
+
+// RegisterRetentionRoutes 注册数据保留策略相关路由。
+//
+// Policies delete tenant data for good, so configuring them is an Admin
+// operation; knowledge base policies also need write access to the KB.
+func RegisterRetentionRoutes(r *gin.RouterGroup, retentionHandler *handler.RetentionHandler, g *rbacGuards) {
+	if retentionHandler == nil {
+		return
+	}
+	policy := r.Group("/retention-policy")
+	{
+		// 获取租户数据保留策略
+		policy.GET("", g.Admin(), retentionHandler.GetTenantPolicy)
+		// 更新租户数据保留策略
+		policy.PUT("", g.Admin(), retentionHandler.UpdateTenantPolicy)
+		// 预览各保留策略将删除的数据
+		policy.POST("/preview", g.Admin(), retentionHandler.PreviewRetention)
+	}
+	kbPolicy := r.Group("/knowledge-bases/:id/retention-policy")
+	{
+		// 获取知识库数据保留策略
+		kbPolicy.GET("", g.Admin(), g.KBAccessRead("id"), retentionHandler.GetKnowledgeBasePolicy)
+		// 更新知识库数据保留策略
+		kbPolicy.PUT("", g.Admin(), g.KBAccessWrite("id"), retentionHandler.UpdateKnowledgeBasePolicy)
+	}
+}
+
+// RegisterIndexMigrationRoutes 注册知识库索引迁移相关路由。
+//
+// A migration rewrites every chunk of the KB, so both starting and
+// aborting one follow the owner-or-admin rule of KB configuration edits.
+func RegisterIndexMigrationRoutes(r *gin.RouterGroup, indexMigrationHandler *handler.IndexMigrationHandler, g *rbacGuards) {
+	if indexMigrationHandler == nil {
+		return
+	}
+	migration := r.Group("/knowledge-bases/:id/index-migration")
+	{
+		// 启动索引迁移
+		migration.POST("", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), indexMigrationHandler.StartIndexMigration)
+		// 取消索引迁移
+		migration.DELETE("", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), indexMigrationHandler.AbortIndexMigration)
+	}
+}
+
 // RegisterMessageRoutes 注册消息相关的路由。
 //
 // Per-session ownership is already enforced inside each handler (the
@@ -448,6 +693,33 @@ func RegisterMessageRoutes(r *gin.RouterGroup, handler *handler.MessageHandler,
 	}
 }
 
+// RegisterFeedbackRoutes 注册回答反馈相关路由。
+//
+// Any member can rate the answers of sessions they can see (the service
+// checks session ownership like the message routes); the tenant-wide stats
+// and exports expose other users' questions, so they are Admin-only.
+func RegisterFeedbackRoutes(r *gin.RouterGroup, feedbackHandler *handler.FeedbackHandler, g *rbacGuards) {
+	if feedbackHandler == nil {
+		return
+	}
+	messages := r.Group("/messages")
+	{
+		// 提交回答反馈
+		messages.PUT("/:session_id/:id/feedback", g.Viewer(), feedbackHandler.SubmitFeedback)
+		// 获取回答反馈
+		messages.GET("/:session_id/:id/feedback", g.Viewer(), feedbackHandler.GetFeedback)
+		// 撤销回答反馈
+		messages.DELETE("/:session_id/:id/feedback", g.Viewer(), feedbackHandler.DeleteFeedback)
+	}
+	feedback := r.Group("/feedback")
+	{
+		// 获取反馈统计
+		feedback.GET("/stats", g.Admin(), feedbackHandler.GetStats)
+		// 导出反馈
+		feedback.GET("/export", g.Admin(), feedbackHandler.Export)
+	}
+}
+
 // RegisterSessionRoutes 注册路由。
 //
 // Sessions are per-user resources; the handler enforces user ownership.
@@ -467,6 +739,7 @@ func RegisterSessionRoutes(r *gin.RouterGroup, handler *session.Handler, g *rbac
 		sessions.DELETE("/:id/messages", handler.ClearSessionMessages)
 		sessions.POST("/:session_id/generate_title", handler.GenerateTitle)
 		sessions.POST("/:session_id/stop", handler.StopSession)
+		sessions.POST("/:session_id/end", handler.EndSession)
 		// POST and DELETE share this path but gin maintains a separate radix tree
 		// per HTTP verb, and the existing trees use different wildcard names
 		// (POST uses :session_id, DELETE uses :id). Use whatever matches each
@@ -481,14 +754,15 @@ func RegisterSessionRoutes(r *gin.RouterGroup, handler *session.Handler, g *rbac
 // RegisterChatRoutes 注册路由。Chat endpoints are tenant-member usage
 // surfaces; Viewer+ is sufficient because per-session/per-agent
 // authorisation is enforced inside the handlers.
-func RegisterChatRoutes(r *gin.RouterGroup, handler *session.Handler, g *rbacGuards) {
-	knowledgeChat := r.Group("/knowledge-chat", g.Viewer())
+func RegisterChatRoutes(r *gin.RouterGroup, handler *session.Handler, g *rbacGuards, quotas *middleware.TenantQuotas) {
+	// 对话请求在整个流式回答期间占用租户的一个并发对话名额
+	knowledgeChat := r.Group("/knowledge-chat", g.Viewer(), quotas.ConcurrentChats())
 	{
 		knowledgeChat.POST("/:session_id", handler.KnowledgeQA)
 	}
 
 	// Agent-based chat
-	agentChat := r.Group("/agent-chat", g.Viewer())
+	agentChat := r.Group("/agent-chat", g.Viewer(), quotas.ConcurrentChats())
 	{
 		agentChat.POST("/:session_id", handler.AgentQA)
 	}
@@ -571,6 +845,9 @@ func RegisterTenantRoutes(
 			tenantByID.PUT("", g.Owner(), handler.UpdateTenant)
 			tenantByID.DELETE("", g.Owner(), handler.DeleteTenant)
 			tenantByID.POST("/api-key", g.Owner(), handler.ResetAPIKey)
+			// The role API key requests act with. Owner-only like the key
+			// itself: lowering it is how a tenant limits a leaked key.
+			tenantByID.PUT("/api-key/role", g.Owner(), handler.UpdateAPIKeyRole)
 
 			// Tenant member management (PR 3 of #1303). Listing is
 			// Viewer+ so any active member can see the roster; mutation
@@ -618,6 +895,14 @@ func RegisterTenantRoutes(
 	}
 }
 
+// RegisterChatCompletionRoutes registers the plain chat completion API: a
+// direct model call without retrieval or session history, optionally with
+// JSON schema output. Viewer+ like the other chat surfaces, and it takes a
+// concurrent chat slot while the model answers.
+func RegisterChatCompletionRoutes(r *gin.RouterGroup, handler *handler.ModelHandler, g *rbacGuards, quotas *middleware.TenantQuotas) {
+	r.POST("/chat/completions", g.Viewer(), quotas.ConcurrentChats(), handler.ChatCompletion)
+}
+
 // Models are tenant-wide infrastructure (LLM credentials, embeddings,
 // rerankers); Viewer+ for reads, Admin+ for any mutation. Credential
 // subresource writes are also Admin+ since secrets are tenant-scoped.
@@ -662,6 +947,94 @@ func RegisterEvaluationRoutes(r *gin.RouterGroup, handler *handler.EvaluationHan
 	}
 }
 
+// RegisterRetrievalEvalRoutes 注册检索评测数据集与评测运行相关路由。
+//
+// Like the legacy evaluation, runs drive model calls against the tenant's
+// KBs, so writes are Admin-only; results are readable by every member.
+func RegisterRetrievalEvalRoutes(r *gin.RouterGroup, evalHandler *handler.RetrievalEvalHandler, g *rbacGuards) {
+	if evalHandler == nil {
+		return
+	}
+	datasets := r.Group("/eval-datasets")
+	{
+		// 创建评测数据集
+		datasets.POST("", g.Admin(), evalHandler.CreateDataset)
+		// 上传评测数据集文件
+		datasets.POST("/upload", g.Admin(), evalHandler.UploadDataset)
+		// 获取评测数据集列表
+		datasets.GET("", g.Viewer(), evalHandler.ListDatasets)
+		// 获取评测数据集详情
+		datasets.GET("/:id", g.Viewer(), evalHandler.GetDataset)
+		// 删除评测数据集
+		datasets.DELETE("/:id", g.Admin(), evalHandler.DeleteDataset)
+	}
+	runs := r.Group("/eval-runs")
+	{
+		// 发起评测运行
+		runs.POST("", g.Admin(), evalHandler.CreateRun)
+		// 获取评测运行列表
+		runs.GET("", g.Viewer(), evalHandler.ListRuns)
+		// 对比评测运行
+		runs.GET("/compare", g.Viewer(), evalHandler.CompareRuns)
+		// 获取评测运行
+		runs.GET("/:id", g.Viewer(), evalHandler.GetRun)
+		// 获取评测运行的逐题结果
+		runs.GET("/:id/results", g.Viewer(), evalHandler.ListRunResults)
+		// 删除评测运行
+		runs.DELETE("/:id", g.Admin(), evalHandler.DeleteRun)
+	}
+}
+
+// RegisterExperimentRoutes 注册影子实验相关路由。
+//
+// Experiments spend model calls on production traffic, so managing them is
+// Admin-only. Win rate reports are readable by every member, but samples
+// hold other users' questions and answers, so listing them is Admin-only
+// like the feedback export.
+func RegisterExperimentRoutes(r *gin.RouterGroup, experimentHandler *handler.ExperimentHandler, g *rbacGuards) {
+	if experimentHandler == nil {
+		return
+	}
+	experiments := r.Group("/experiments")
+	{
+		// 创建影子实验
+		experiments.POST("", g.Admin(), experimentHandler.CreateExperiment)
+		// 获取影子实验列表
+		experiments.GET("", g.Viewer(), experimentHandler.ListExperiments)
+		// 获取影子实验
+		experiments.GET("/:id", g.Viewer(), experimentHandler.GetExperiment)
+		// 更新影子实验
+		experiments.PUT("/:id", g.Admin(), experimentHandler.UpdateExperiment)
+		// 删除影子实验
+		experiments.DELETE("/:id", g.Admin(), experimentHandler.DeleteExperiment)
+		// 获取影子实验样本
+		experiments.GET("/:id/samples", g.Admin(), experimentHandler.ListSamples)
+	}
+}
+
+// RegisterAnalyticsRoutes 注册问答分析相关路由。
+//
+// The analytics aggregate every member's questions across the tenant, so
+// they are Admin-only like the feedback stats.
+func RegisterAnalyticsRoutes(r *gin.RouterGroup, analyticsHandler *handler.AnalyticsHandler, g *rbacGuards) {
+	if analyticsHandler == nil {
+		return
+	}
+	analytics := r.Group("/analytics", g.Admin())
+	{
+		// 获取问答概览
+		analytics.GET("/overview", analyticsHandler.GetOverview)
+		// 获取热门提问
+		analytics.GET("/top-queries", analyticsHandler.TopQueries)
+		// 获取无结果提问
+		analytics.GET("/zero-result-queries", analyticsHandler.ZeroResultQueries)
+		// 获取最常引用的文档
+		analytics.GET("/documents", analyticsHandler.TopDocuments)
+		// 获取知识库使用情况
+		analytics.GET("/knowledge-bases", analyticsHandler.KnowledgeBaseUsage)
+	}
+}
+
 // RegisterMyInvitationRoutes wires the per-user invitation inbox under
 // /me/invitations. The v1 group already applies middleware.Auth so we
 // don't need a role gate here — the service enforces "only the invitee
@@ -738,6 +1111,7 @@ func RegisterInitializationRoutes(r *gin.RouterGroup, handler *handler.Initializ
 	r.POST("/initialization/embedding/test", g.Admin(), handler.TestEmbeddingModel)
 	r.POST("/initialization/rerank/check", g.Admin(), handler.CheckRerankModel)
 	r.POST("/initialization/asr/check", g.Admin(), handler.CheckASRModel)
+	r.POST("/initialization/models/probe", g.Admin(), handler.ProbeModel)
 	r.POST("/initialization/multimodal/test", g.Admin(), handler.TestMultimodalFunction)
 
 	r.POST("/initialization/extract/text-relation", g.Admin(), handler.ExtractTextRelations)
@@ -764,6 +1138,17 @@ func RegisterSystemRoutes(r *gin.RouterGroup, handler *handler.SystemHandler, g
 	}
 }
 
+// RegisterHealthRoutes registers the dependency health report under
+// /system. Unlike the public /health liveness probe it reaches every
+// dependency and lists the tenant's model endpoints, so it is Admin+;
+// load balancers call it with an admin API key.
+func RegisterHealthRoutes(r *gin.RouterGroup, healthHandler *handler.HealthHandler, g *rbacGuards) {
+	if healthHandler == nil {
+		return
+	}
+	r.GET("/system/health", g.Admin(), healthHandler.GetSystemHealth)
+}
+
 // RegisterMCPServiceRoutes registers MCP service routes.
 //
 // MCP services are tenant-level integrations (external tool servers); we
@@ -820,6 +1205,9 @@ func RegisterSystemAdminRoutes(
 			handler.ApplyDefaultStorageQuotaToAllTenants,
 		)
 
+		// Batch embedding throughput counters, to watch indexing speed.
+		adminRoutes.GET("/embedding-stats", handler.GetEmbeddingStats)
+
 		// Platform-wide audit feed (tenant_id=0 rows). Covers
 		// system.setting_changed / system.admin_promoted /
 		// system.admin_revoked etc. — events written by the routes
@@ -936,6 +1324,42 @@ func RegisterWebSearchProviderRoutes(
 	}
 }
 
+// RegisterSQLConnectionRoutes registers CRUD routes for the databases
+// knowledge QA may answer analytical questions from.
+//
+// Connection rows hold database credentials; reads are Viewer+, all
+// mutations and connection tests are Admin+.
+func RegisterSQLConnectionRoutes(r *gin.RouterGroup, h *handler.SQLConnectionHandler, g *rbacGuards) {
+	conns := r.Group("/sql-connections")
+	{
+		// Test unsaved parameters (no persistence) — Admin+
+		conns.POST("/test", g.Admin(), h.TestConnectionRaw)
+		// CRUD
+		conns.POST("", g.Admin(), h.CreateConnection)
+		conns.GET("", g.Viewer(), h.ListConnections)
+		conns.GET("/:id", g.Viewer(), h.GetConnection)
+		conns.PUT("/:id", g.Admin(), h.UpdateConnection)
+		conns.DELETE("/:id", g.Admin(), h.DeleteConnection)
+		// Test existing saved connection — Admin+
+		conns.POST("/:id/test", g.Admin(), h.TestConnectionByID)
+	}
+}
+
+// RegisterTokenUsageRoutes registers the token usage report and the tenant
+// token budget routes.
+//
+// The report breaks usage down by session and model, and the budget caps
+// model calls for everyone in the tenant; the report and budget changes are
+// Admin+, reading the budget is Viewer+.
+func RegisterTokenUsageRoutes(r *gin.RouterGroup, h *handler.TokenUsageHandler, g *rbacGuards) {
+	usage := r.Group("/usage")
+	{
+		usage.GET("", g.Admin(), h.GetUsage)
+		usage.GET("/budget", g.Viewer(), h.GetBudget)
+		usage.PUT("/budget", g.Admin(), h.UpdateBudget)
+	}
+}
+
 // RegisterVectorStoreRoutes registers CRUD routes for vector store configurations.
 //
 // Vector stores are tenant-level infrastructure; reads are Viewer+, all
@@ -956,6 +1380,14 @@ func RegisterVectorStoreRoutes(r *gin.RouterGroup, h *handler.VectorStoreHandler
 		stores.DELETE("/:id", g.Admin(), h.DeleteStore)
 		// Test existing saved or env store — Admin+
 		stores.POST("/:id/test", g.Admin(), h.TestStoreByID)
+		// Rebuild collections with the current index_config — Admin+
+		stores.POST("/:id/reindex", g.Admin(), h.ReindexStore)
+		stores.POST("/:id/dedup", g.Admin(), h.DedupStore)
+		stores.POST("/:id/migrate-model-spaces", g.Admin(), h.MigrateStoreModelSpaces)
+		// Dry-run a metadata filter against the store schema — Viewer+
+		stores.POST("/:id/validate-filter", g.Viewer(), h.ValidateFilter)
+		stores.GET("/:id/capabilities", g.Viewer(), h.GetStoreCapabilities)
+		stores.GET("/:id/load-state", g.Viewer(), h.GetStoreLoadState)
 	}
 }
 
@@ -1005,6 +1437,23 @@ func RegisterUserFavoriteRoutes(r *gin.RouterGroup, h *handler.UserResourceFavor
 	}
 }
 
+// RegisterMemoryRoutes wires the endpoints users inspect and correct their
+// conversation memory with. Like favorites, the handler scopes everything
+// to the calling user, so a Viewer floor is the right gate.
+func RegisterMemoryRoutes(r *gin.RouterGroup, h *handler.MemoryHandler, g *rbacGuards) {
+	mem := r.Group("/user/memory")
+	{
+		mem.GET("/profile", g.Viewer(), h.GetProfile)
+		mem.DELETE("/profile/:category/:key", g.Viewer(), h.DeleteProfileFact)
+		mem.GET("/episodes", g.Viewer(), h.ListEpisodes)
+		mem.DELETE("/episodes/:id", g.Viewer(), h.DeleteEpisode)
+		mem.GET("/entities", g.Viewer(), h.ListEntities)
+		mem.PUT("/entities", g.Viewer(), h.UpdateEntity)
+		mem.GET("/relationships", g.Viewer(), h.ListRelationships)
+		mem.GET("/export", g.Viewer(), h.ExportMemory)
+	}
+}
+
 // RegisterSkillRoutes registers skill routes.
 //
 // PR 2 currently only exposes a read-only `ListSkills`; gated to
@@ -1201,6 +1650,13 @@ func RegisterIMRoutes(r *gin.Engine, imHandler *handler.IMHandler) {
 	}
 }
 
+// RegisterDataSourceWebhookRoutes registers the public webhook endpoint of
+// data sources. Deliveries are authenticated by the connector (e.g. the
+// HMAC signature of a git push webhook), not by the Auth middleware.
+func RegisterDataSourceWebhookRoutes(r *gin.Engine, handler *handler.DataSourceHandler) {
+	r.POST("/api/v1/webhooks/datasource/:id", handler.HandleWebhook)
+}
+
 // RegisterIMChannelRoutes registers IM channel CRUD routes (requires authentication).
 //
 // IM channels carry external bot credentials (WeChat/Feishu/Slack/...);
@@ -1343,7 +1799,8 @@ func serveFrontendStatic(r *gin.Engine) {
 			return
 		}
 		path := c.Request.URL.Path
-		if strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/health") || strings.HasPrefix(path, "/swagger/") {
+		if strings.HasPrefix(path, "/api/") || strings.HasPrefix(path, "/health") || strings.HasPrefix(path, "/metrics") ||
+			strings.HasPrefix(path, "/swagger/") {
 			c.Next()
 			return
 		}
@@ -1449,12 +1906,16 @@ func newFileServeHandler(globalFileService interfaces.FileService) gin.HandlerFu
 			}
 		}
 
-		reader, err := fileSvc.GetFile(c.Request.Context(), filePath)
+		reader, status, err := openRequestedFile(c, fileSvc, filePath)
 		if err != nil {
 			logger.Warnf(context.Background(), "[Router] /files get file failed: tenant_id=%d provider=%s path=%q err=%v", tenant.ID, resolvedProvider, filePath, err)
 			c.Status(http.StatusNotFound)
 			return
 		}
+		if reader == nil {
+			c.Status(status)
+			return
+		}
 		defer reader.Close()
 
 		ext := filepath.Ext(filePath)
@@ -1480,13 +1941,49 @@ func newFileServeHandler(globalFileService interfaces.FileService) gin.HandlerFu
 
 		c.Header("Content-Type", contentType)
 		c.Header("Cache-Control", "public, max-age=86400")
-		c.Status(http.StatusOK)
+		c.Status(status)
 		if _, err := io.Copy(c.Writer, reader); err != nil {
 			logger.Warnf(context.Background(), "[Router] /files write response failed: %v", err)
 		}
 	}
 }
 
+// openRequestedFile opens filePath for the response. A single-range Range
+// header is served with a ranged read, so players and PDF viewers can seek
+// in large files and clients can resume downloads; the 206 headers are set
+// here. The returned status is the one to answer with. A nil reader without
+// error means the range is not satisfiable (416).
+func openRequestedFile(c *gin.Context, fileSvc interfaces.FileService, filePath string) (io.ReadCloser, int, error) {
+	c.Header("Accept-Ranges", "bytes")
+	// The object size is unknown until it is read, so suffix ranges fall
+	// back to the whole file.
+	offset, length, ranged := secutils.ParseRangeHeader(c.GetHeader("Range"), -1)
+	if !ranged || c.GetHeader("If-Range") != "" {
+		reader, err := fileSvc.GetFile(c.Request.Context(), filePath)
+		return reader, http.StatusOK, err
+	}
+
+	reader, size, err := fileSvc.GetFileRange(c.Request.Context(), filePath, offset, length)
+	if errors.Is(err, filesvc.ErrInvalidRange) {
+		if size >= 0 {
+			c.Header("Content-Range", fmt.Sprintf("bytes */%d", size))
+		}
+		return nil, http.StatusRequestedRangeNotSatisfiable, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	contentRange, n := secutils.ContentRange(offset, length, size)
+	if contentRange == "" {
+		return reader, http.StatusOK, nil
+	}
+	c.Header("Content-Range", contentRange)
+	if n >= 0 {
+		c.Header("Content-Length", strconv.FormatInt(n, 10))
+	}
+	return reader, http.StatusPartialContent, nil
+}
+
 func serveFiles(r getRouteRegistrar, globalFileService interfaces.FileService) {
 	logger.Infof(context.Background(), "[Router] Serving files from /files")
 	r.GET("/files", newFileServeHandler(globalFileService))
@@ -1603,22 +2100,26 @@ func presignedFileHandler(tenantService interfaces.TenantService, absDir string)
 		// Skipping GetFile entirely for HEAD would risk reporting 200 for a
 		// signed URL that no longer points at a real object; that mismatch
 		// would make subsequent GETs from the same client mysteriously fail.
-		reader, err := fileSvc.GetFile(ctx, filePath)
+		reader, status, err := openRequestedFile(c, fileSvc, filePath)
 		if err != nil {
 			logger.Warnf(ctx, "[Router] /files/presigned get file failed: client_ip=%s tenant_id=%d provider=%s path=%q err=%v",
 				clientIP, tenantID, resolvedProvider, filePath, err)
 			c.Status(http.StatusNotFound)
 			return
 		}
+		if reader == nil {
+			c.Status(status)
+			return
+		}
 		defer reader.Close()
 
 		c.Header("Content-Type", contentType)
 		c.Header("Cache-Control", "public, max-age=86400")
 		if c.Request.Method == http.MethodHead {
-			c.Status(http.StatusOK)
+			c.Status(status)
 			return
 		}
-		c.Status(http.StatusOK)
+		c.Status(status)
 		if _, err := io.Copy(c.Writer, reader); err != nil {
 			logger.Warnf(ctx, "[Router] /files/presigned write response failed: client_ip=%s tenant_id=%d err=%v", clientIP, tenantID, err)
 		}
@@ -1633,7 +2134,7 @@ func presignedFileHandler(tenantService interfaces.TenantService, absDir string)
 // send a real message through an IM bot.
 //
 // Route:
-//   - GET /api/v1/files/presigned-preview?file_path=<provider://...>
+//   - GET /api/v1/files/presigned-preview?file_path=<provider://...>[&expires_in=<seconds>]
 func servePresignedPreview(r *gin.Engine, cfg *config.Config) {
 	baseDir := os.Getenv("LOCAL_STORAGE_BASE_DIR")
 	if baseDir == "" {
@@ -1654,6 +2155,17 @@ func servePresignedPreview(r *gin.Engine, cfg *config.Config) {
 				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid file path"})
 				return
 			}
+			var expiry time.Duration
+			if v := c.Query("expires_in"); v != "" {
+				seconds, err := strconv.Atoi(v)
+				if err != nil || seconds <= 0 || seconds > types.MaxFileURLExpirySeconds {
+					c.JSON(http.StatusBadRequest, gin.H{
+						"error": fmt.Sprintf("expires_in must be between 1 and %d seconds", types.MaxFileURLExpirySeconds),
+					})
+					return
+				}
+				expiry = time.Duration(seconds) * time.Second
+			}
 
 			tenant, _ := ctx.Value(types.TenantInfoContextKey).(*types.Tenant)
 			if tenant == nil {
@@ -1672,7 +2184,7 @@ func servePresignedPreview(r *gin.Engine, cfg *config.Config) {
 				return
 			}
 
-			httpURL, err := fileSvc.GetFileURL(ctx, filePath)
+			httpURL, err := filesvc.GetFileURLWithExpiry(ctx, fileSvc, filePath, expiry)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{
 					"error":    err.Error(),
@@ -1804,3 +2316,20 @@ func RegisterWikiPageRoutes(r *gin.RouterGroup, wikiHandler *handler.WikiPageHan
 		wiki.PUT("/issues/:issue_id/status", g.OwnedWikiKBOrAdmin(), wikiHandler.UpdateIssueStatus)
 	}
 }
+
+// RegisterKnowledgeGapRoutes 注册知识缺口报告相关路由。
+//
+// Gaps are questions users asked the KB, so only those who may change its
+// content — the owner-or-admin rule of duplicate removal — see and dismiss them.
+func RegisterKnowledgeGapRoutes(r *gin.RouterGroup, gapHandler *handler.KnowledgeGapHandler, g *rbacGuards) {
+	if gapHandler == nil {
+		return
+	}
+	gaps := r.Group("/knowledge-bases/:id/knowledge-gaps")
+	{
+		// 获取按相似度聚类的知识缺口报告
+		gaps.GET("", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), gapHandler.GetGapReport)
+		// 补充文档后忽略知识缺口
+		gaps.POST("/dismiss", g.OwnedKBOrAdmin(), g.KBAccessWrite("id"), gapHandler.DismissGaps)
+	}
+}
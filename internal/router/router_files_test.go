@@ -12,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 
+	filesvc "github.com/Tencent/WeKnora/internal/application/service/file"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 )
@@ -19,7 +20,8 @@ import (
 var _ interfaces.FileService = (*stubFileService)(nil)
 
 type stubFileService struct {
-	getFile func(ctx context.Context, filePath string) (io.ReadCloser, error)
+	getFile      func(ctx context.Context, filePath string) (io.ReadCloser, error)
+	getFileRange func(ctx context.Context, filePath string, offset, length int64) (io.ReadCloser, int64, error)
 }
 
 func (s *stubFileService) CheckConnectivity(ctx context.Context) error {
@@ -41,6 +43,13 @@ func (s *stubFileService) GetFile(ctx context.Context, filePath string) (io.Read
 	return s.getFile(ctx, filePath)
 }
 
+func (s *stubFileService) GetFileRange(ctx context.Context, filePath string, offset, length int64) (io.ReadCloser, int64, error) {
+	if s.getFileRange == nil {
+		panic("unexpected call to GetFileRange")
+	}
+	return s.getFileRange(ctx, filePath, offset, length)
+}
+
 func (s *stubFileService) GetFileURL(ctx context.Context, filePath string) (string, error) {
 	panic("unexpected call to GetFileURL")
 }
@@ -152,3 +161,51 @@ func TestServeFilesRejectsPathWithoutTenantSegment(t *testing.T) {
 		t.Fatalf("status = %d, want %d", got, want)
 	}
 }
+
+func TestServeFilesHonorsRangeHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("STORAGE_TYPE", "local")
+
+	const content = "0123456789"
+	engine := gin.New()
+	serveFiles(engine, &stubFileService{
+		getFileRange: func(ctx context.Context, filePath string, offset, length int64) (io.ReadCloser, int64, error) {
+			if offset >= int64(len(content)) {
+				return nil, int64(len(content)), filesvc.ErrInvalidRange
+			}
+			end := int64(len(content))
+			if length > 0 && offset+length < end {
+				end = offset + length
+			}
+			return io.NopCloser(strings.NewReader(content[offset:end])), int64(len(content)), nil
+		},
+	})
+
+	serve := func(rangeHeader string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/files?file_path="+url.QueryEscape("local://42/docs/video.mp4"), nil)
+		req.Header.Set("Range", rangeHeader)
+		req = req.WithContext(context.WithValue(req.Context(), types.TenantInfoContextKey, &types.Tenant{ID: 42}))
+		recorder := httptest.NewRecorder()
+		engine.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := serve("bytes=2-4")
+	if got, want := recorder.Code, http.StatusPartialContent; got != want {
+		t.Fatalf("status = %d, want %d", got, want)
+	}
+	if got, want := recorder.Header().Get("Content-Range"), "bytes 2-4/10"; got != want {
+		t.Fatalf("Content-Range = %q, want %q", got, want)
+	}
+	if body := recorder.Body.String(); body != "234" {
+		t.Fatalf("body = %q, want %q", body, "234")
+	}
+
+	recorder = serve("bytes=20-")
+	if got, want := recorder.Code, http.StatusRequestedRangeNotSatisfiable; got != want {
+		t.Fatalf("status = %d, want %d", got, want)
+	}
+	if got, want := recorder.Header().Get("Content-Range"), "bytes */10"; got != want {
+		t.Fatalf("Content-Range = %q, want %q", got, want)
+	}
+}
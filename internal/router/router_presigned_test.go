@@ -54,6 +54,9 @@ func (s *stubTenantService) DeleteTenant(context.Context, uint64) error { panic(
 func (s *stubTenantService) UpdateAPIKey(context.Context, uint64) (string, error) {
 	panic("unexpected")
 }
+func (s *stubTenantService) UpdateAPIKeyRole(context.Context, uint64, types.TenantRole) (*types.Tenant, error) {
+	panic("unexpected")
+}
 func (s *stubTenantService) ExtractTenantIDFromAPIKey(string) (uint64, error) { panic("unexpected") }
 func (s *stubTenantService) ListAllTenants(context.Context) ([]*types.Tenant, error) {
 	panic("unexpected")
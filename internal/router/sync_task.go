@@ -19,11 +19,15 @@ import (
 type SyncTaskExecutor struct {
 	mu       sync.RWMutex
 	handlers map[string]func(context.Context, *asynq.Task) error
+	// running holds the IDs of tasks enqueued with asynq.TaskID that have
+	// not finished yet.
+	running map[string]struct{}
 }
 
 func NewSyncTaskExecutor() *SyncTaskExecutor {
 	return &SyncTaskExecutor{
 		handlers: make(map[string]func(context.Context, *asynq.Task) error),
+		running:  make(map[string]struct{}),
 	}
 }
 
@@ -36,7 +40,9 @@ func (e *SyncTaskExecutor) RegisterHandler(pattern string, handler func(context.
 
 // Enqueue satisfies interfaces.TaskEnqueuer.
 // Instead of queuing to Redis, it dispatches the task to a goroutine.
-// Supports ProcessIn (delay) and MaxRetry options for parity with asynq.
+// Supports ProcessIn (delay), MaxRetry and TaskID options for parity with
+// asynq; a TaskID is rejected with asynq.ErrTaskIDConflict while a task
+// with the same ID is still running.
 func (e *SyncTaskExecutor) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asynq.TaskInfo, error) {
 	e.mu.RLock()
 	handler, ok := e.handlers[task.Type()]
@@ -46,6 +52,7 @@ func (e *SyncTaskExecutor) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asy
 		return nil, fmt.Errorf("sync task executor: no handler registered for type %q", task.Type())
 	}
 
+	var uniqueID string
 	var delay time.Duration
 	maxRetry := 25 // asynq default
 	maxRetrySet := false
@@ -60,6 +67,10 @@ func (e *SyncTaskExecutor) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asy
 				maxRetry = n
 				maxRetrySet = true
 			}
+		case asynq.TaskIDOpt:
+			if id, ok := opt.Value().(string); ok {
+				uniqueID = id
+			}
 		}
 	}
 	// Callers that explicitly pass MaxRetry(0) want no retries.
@@ -69,6 +80,16 @@ func (e *SyncTaskExecutor) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asy
 	}
 
 	taskID := uuid.New().String()
+	if uniqueID != "" {
+		e.mu.Lock()
+		if _, dup := e.running[uniqueID]; dup {
+			e.mu.Unlock()
+			return nil, asynq.ErrTaskIDConflict
+		}
+		e.running[uniqueID] = struct{}{}
+		e.mu.Unlock()
+		taskID = uniqueID
+	}
 	info := &asynq.TaskInfo{
 		ID:    taskID,
 		Queue: "sync",
@@ -76,6 +97,13 @@ func (e *SyncTaskExecutor) Enqueue(task *asynq.Task, opts ...asynq.Option) (*asy
 	}
 
 	go func() {
+		if uniqueID != "" {
+			defer func() {
+				e.mu.Lock()
+				delete(e.running, uniqueID)
+				e.mu.Unlock()
+			}()
+		}
 		if delay > 0 {
 			time.Sleep(delay)
 		}
@@ -119,11 +147,17 @@ type SyncTaskParams struct {
 	KnowledgeBaseService interfaces.KnowledgeBaseService
 	TagService           interfaces.KnowledgeTagService
 	DataSourceService    interfaces.DataSourceService
+	GraphCommunity       interfaces.GraphCommunityService
+	DuplicateService     interfaces.DuplicateService
+	AutoTagService       interfaces.AutoTagService
+	RetrievalEval        interfaces.RetrievalEvalService
+	Experiment           interfaces.ExperimentService
 	ChunkExtractor       interfaces.TaskHandler `name:"chunkExtractor"`
 	DataTableSummary     interfaces.TaskHandler `name:"dataTableSummary"`
 	ImageMultimodal      interfaces.TaskHandler `name:"imageMultimodal"`
 	KnowledgePostProcess interfaces.TaskHandler `name:"knowledgePostProcess"`
 	WikiIngest           interfaces.TaskHandler `name:"wikiIngest"`
+	MemoryExtractor      interfaces.TaskHandler `name:"memoryExtractor"`
 }
 
 // RegisterSyncHandlers registers all task handlers on the SyncTaskExecutor.
@@ -134,6 +168,9 @@ func RegisterSyncHandlers(params SyncTaskParams) {
 	params.Executor.RegisterHandler(types.TypeDocumentProcess, params.KnowledgeService.ProcessDocument)
 	params.Executor.RegisterHandler(types.TypeManualProcess, params.KnowledgeService.ProcessManualUpdate)
 	params.Executor.RegisterHandler(types.TypeFAQImport, params.KnowledgeService.ProcessFAQImport)
+	params.Executor.RegisterHandler(types.TypeChunkImport, params.KnowledgeService.ProcessChunkImport)
+	params.Executor.RegisterHandler(types.TypeKBSnapshot, params.KnowledgeService.ProcessKBSnapshot)
+	params.Executor.RegisterHandler(types.TypeKBRestore, params.KnowledgeService.ProcessKBRestore)
 	params.Executor.RegisterHandler(types.TypeQuestionGeneration, params.KnowledgeService.ProcessQuestionGeneration)
 	params.Executor.RegisterHandler(types.TypeSummaryGeneration, params.KnowledgeService.ProcessSummaryGeneration)
 	params.Executor.RegisterHandler(types.TypeKBClone, params.KnowledgeService.ProcessKBClone)
@@ -146,5 +183,11 @@ func RegisterSyncHandlers(params SyncTaskParams) {
 	params.Executor.RegisterHandler(types.TypeKnowledgePostProcess, params.KnowledgePostProcess.Handle)
 	params.Executor.RegisterHandler(types.TypeDataSourceSync, params.DataSourceService.ProcessSync)
 	params.Executor.RegisterHandler(types.TypeWikiIngest, params.WikiIngest.Handle)
+	params.Executor.RegisterHandler(types.TypeMemoryExtract, params.MemoryExtractor.Handle)
+	params.Executor.RegisterHandler(types.TypeGraphCommunityBuild, params.GraphCommunity.ProcessCommunityBuild)
+	params.Executor.RegisterHandler(types.TypeDuplicateAnalysis, params.DuplicateService.ProcessDuplicateAnalysis)
+	params.Executor.RegisterHandler(types.TypeAutoTag, params.AutoTagService.ProcessAutoTag)
+	params.Executor.RegisterHandler(types.TypeEvalRun, params.RetrievalEval.ProcessEvalRun)
+	params.Executor.RegisterHandler(types.TypeExperimentShadow, params.Experiment.ProcessShadowAnswer)
 	logger.Infof(context.Background(), "[SyncTask] All task handlers registered (Lite mode, no Redis)")
 }
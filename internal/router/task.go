@@ -14,6 +14,7 @@ import (
 	"github.com/Tencent/WeKnora/internal/logger"
 	"github.com/Tencent/WeKnora/internal/middleware/asynqdl"
 	"github.com/Tencent/WeKnora/internal/tracing/langfuse"
+	"github.com/Tencent/WeKnora/internal/tracing/telemetry"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/Tencent/WeKnora/internal/types/interfaces"
 	"github.com/hibiken/asynq"
@@ -28,11 +29,19 @@ type AsynqTaskParams struct {
 	KnowledgeBaseService interfaces.KnowledgeBaseService
 	TagService           interfaces.KnowledgeTagService
 	DataSourceService    interfaces.DataSourceService
+	GlossaryService      interfaces.GlossaryService
+	GraphCommunity       interfaces.GraphCommunityService
+	DuplicateService     interfaces.DuplicateService
+	AutoTagService       interfaces.AutoTagService
+	RetrievalEval        interfaces.RetrievalEvalService
+	Experiment           interfaces.ExperimentService
+	IndexMigration       interfaces.IndexMigrationService
 	ChunkExtractor       interfaces.TaskHandler `name:"chunkExtractor"`
 	DataTableSummary     interfaces.TaskHandler `name:"dataTableSummary"`
 	ImageMultimodal      interfaces.TaskHandler `name:"imageMultimodal"`
 	KnowledgePostProcess interfaces.TaskHandler `name:"knowledgePostProcess"`
 	WikiIngest           interfaces.TaskHandler `name:"wikiIngest"`
+	MemoryExtractor      interfaces.TaskHandler `name:"memoryExtractor"`
 	DeadLetterRepo       interfaces.TaskDeadLetterRepository
 	SpanTracker          service.SpanTracker
 }
@@ -142,6 +151,8 @@ func NewAsynqServer(svc interfaces.SystemSettingService) *asynq.Server {
 				types.QueueMultimodal: 1, // Isolated lane for high-volume slow VLM image tasks
 				types.QueueGraph:      1, // Isolated lane for high-volume slow graph-extraction tasks
 				types.QueueQuestion:   1, // Isolated lane for high-volume slow question-generation tasks
+				types.QueueMemory:     1, // Isolated lane for per-turn conversation memory extraction
+				types.QueueBulk:       1, // Bulk document ingestion (data source sync, batch reparse)
 			},
 			RetryDelayFunc: asynqRetryDelayFunc,
 		},
@@ -177,6 +188,10 @@ func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
 	// chat / rerank / ASR) nest correctly in the Langfuse UI.
 	mux.Use(langfuse.AsynqMiddleware())
 
+	// OpenTelemetry counterpart: a consumer span per task that continues the
+	// enqueuing request's trace from the traceparent in the payload.
+	mux.Use(telemetry.AsynqMiddleware())
+
 	// Register extract handlers - router will dispatch to appropriate handler
 	mux.HandleFunc(types.TypeChunkExtract, params.ChunkExtractor.Handle)
 	mux.HandleFunc(types.TypeDataTableSummary, params.DataTableSummary.Handle)
@@ -190,6 +205,13 @@ func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
 	// Register FAQ import handler (includes dry run mode)
 	mux.HandleFunc(types.TypeFAQImport, params.KnowledgeService.ProcessFAQImport)
 
+	// Register pre-chunked data import handler
+	mux.HandleFunc(types.TypeChunkImport, params.KnowledgeService.ProcessChunkImport)
+
+	// Register knowledge base snapshot and restore handlers
+	mux.HandleFunc(types.TypeKBSnapshot, params.KnowledgeService.ProcessKBSnapshot)
+	mux.HandleFunc(types.TypeKBRestore, params.KnowledgeService.ProcessKBRestore)
+
 	// Register question generation handler
 	mux.HandleFunc(types.TypeQuestionGeneration, params.KnowledgeService.ProcessQuestionGeneration)
 
@@ -226,6 +248,33 @@ func RunAsynqServer(params AsynqTaskParams) *asynq.ServeMux {
 	// Register wiki ingest handler
 	mux.HandleFunc(types.TypeWikiIngest, params.WikiIngest.Handle)
 
+	// Register glossary build handler
+	mux.HandleFunc(types.TypeGlossaryBuild, params.GlossaryService.ProcessGlossaryBuild)
+
+	// Register graph community build handler
+	mux.HandleFunc(types.TypeGraphCommunityBuild, params.GraphCommunity.ProcessCommunityBuild)
+
+	// Register duplicate analysis handler
+	mux.HandleFunc(types.TypeDuplicateAnalysis, params.DuplicateService.ProcessDuplicateAnalysis)
+
+	// Register auto-tag handler
+	mux.HandleFunc(types.TypeAutoTag, params.AutoTagService.ProcessAutoTag)
+
+	// Register retrieval evaluation run handler
+	mux.HandleFunc(types.TypeEvalRun, params.RetrievalEval.ProcessEvalRun)
+
+	// Register shadow experiment answer handler
+	mux.HandleFunc(types.TypeExperimentShadow, params.Experiment.ProcessShadowAnswer)
+
+	// Register index migration handler
+	mux.HandleFunc(types.TypeIndexMigration, params.IndexMigration.ProcessIndexMigration)
+
+	// Register file preview handler
+	mux.HandleFunc(types.TypeFilePreview, params.KnowledgeService.ProcessFilePreview)
+
+	// Register conversation memory extraction handler
+	mux.HandleFunc(types.TypeMemoryExtract, params.MemoryExtractor.Handle)
+
 	go func() {
 		// Start the server
 		if err := params.Server.Run(mux); err != nil {
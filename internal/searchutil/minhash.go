@@ -0,0 +1,232 @@
+package searchutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// MinHash near-duplicate detection for chunk and document text.
+//
+// Text is normalized (lower case, letters and digits only) and split into
+// overlapping rune shingles, so it works the same for CJK text without word
+// boundaries and for Latin text. A signature keeps, for each of
+// MinHashSize hash functions, the minimum hash over the shingles; the share
+// of equal positions between two signatures estimates the Jaccard similarity
+// of their shingle sets. Signatures are bucketed by band (LSH) so only
+// likely pairs are compared.
+
+const (
+	// MinHashSize is the number of hash functions in a signature.
+	MinHashSize = 128
+	// minHashBands x minHashRows must equal MinHashSize. Four rows per band
+	// make pairs above ~0.5 similarity very likely to share a bucket.
+	minHashBands = 32
+	minHashRows  = MinHashSize / minHashBands
+	// minHashShingle is the shingle length in runes.
+	minHashShingle = 5
+	// minHashMaxCompare caps the later members of one bucket an item is
+	// compared with, so boilerplate repeated many times stays linear.
+	minHashMaxCompare = 64
+)
+
+// MinHashSignature is the MinHash signature of one text.
+type MinHashSignature []uint32
+
+var minHashSeeds = func() [MinHashSize]uint64 {
+	var seeds [MinHashSize]uint64
+	x := uint64(0x5eed)
+	for i := range seeds {
+		x = splitMix64(x)
+		seeds[i] = x
+	}
+	return seeds
+}()
+
+func splitMix64(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// NormalizeForDedup lower-cases text and keeps only its letters and digits,
+// so whitespace, punctuation and markup differences do not hide duplicates.
+func NormalizeForDedup(text string) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// DedupFingerprint returns the hex SHA-256 of normalized text. Texts with the
+// same fingerprint are exact duplicates.
+func DedupFingerprint(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewMinHashSignature computes the signature of normalized text. Text shorter
+// than one shingle is a single shingle.
+func NewMinHashSignature(normalized string) MinHashSignature {
+	sig := make(MinHashSignature, MinHashSize)
+	for i := range sig {
+		sig[i] = ^uint32(0)
+	}
+	runes := []rune(normalized)
+	if len(runes) == 0 {
+		return sig
+	}
+	n := len(runes) - minHashShingle + 1
+	if n < 1 {
+		n = 1
+	}
+	for start := 0; start < n; start++ {
+		end := min(start+minHashShingle, len(runes))
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(string(runes[start:end])))
+		base := h.Sum64()
+		for i, seed := range minHashSeeds {
+			if v := uint32(splitMix64(base ^ seed)); v < sig[i] {
+				sig[i] = v
+			}
+		}
+	}
+	return sig
+}
+
+// Merge folds other into s so s becomes the signature of the union of both
+// shingle sets. A document's signature is the merge of its chunks'.
+func (s MinHashSignature) Merge(other MinHashSignature) {
+	for i := range s {
+		if other[i] < s[i] {
+			s[i] = other[i]
+		}
+	}
+}
+
+// Similarity estimates the Jaccard similarity of the texts behind two
+// signatures.
+func (s MinHashSignature) Similarity(other MinHashSignature) float64 {
+	equal := 0
+	for i := range s {
+		if s[i] == other[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(s))
+}
+
+// DedupItem is one text taking part in duplicate detection.
+type DedupItem struct {
+	// Fingerprint identifies exact duplicates; see DedupFingerprint
+	Fingerprint string
+	// Signature finds near duplicates; nil takes part in exact matching only
+	Signature MinHashSignature
+}
+
+// DedupMatch is an item found to duplicate a kept item.
+type DedupMatch struct {
+	Index      int
+	Exact      bool
+	Similarity float64
+}
+
+// DedupCluster is a kept item and the items duplicating it.
+type DedupCluster struct {
+	Keep       int
+	Duplicates []DedupMatch
+}
+
+// FindDuplicates groups items that duplicate an earlier item, so callers
+// order items by which copy they would rather keep. Every duplicate is an
+// exact copy of its cluster's kept item or has an estimated similarity of at
+// least threshold to the kept item itself, never only to another duplicate.
+// Clusters are in the order of their kept item, duplicates in item order.
+func FindDuplicates(items []DedupItem, threshold float64) []DedupCluster {
+	assigned := make([]bool, len(items))
+	matches := make(map[int][]DedupMatch)
+
+	firstByFingerprint := make(map[string]int, len(items))
+	for i, item := range items {
+		if item.Fingerprint == "" {
+			continue
+		}
+		if keep, ok := firstByFingerprint[item.Fingerprint]; ok {
+			assigned[i] = true
+			matches[keep] = append(matches[keep], DedupMatch{Index: i, Exact: true, Similarity: 1})
+			continue
+		}
+		firstByFingerprint[item.Fingerprint] = i
+	}
+
+	buckets := make(map[uint64][]int)
+	keys := make([][]uint64, len(items))
+	for i, item := range items {
+		if assigned[i] || len(item.Signature) != MinHashSize {
+			continue
+		}
+		keys[i] = make([]uint64, minHashBands)
+		for band := 0; band < minHashBands; band++ {
+			key := bandKey(band, item.Signature[band*minHashRows:(band+1)*minHashRows])
+			keys[i][band] = key
+			buckets[key] = append(buckets[key], i)
+		}
+	}
+
+	for i, item := range items {
+		if assigned[i] || keys[i] == nil {
+			continue
+		}
+		seen := make(map[int]bool)
+		for _, key := range keys[i] {
+			members := buckets[key]
+			compared := 0
+			for _, j := range members[sort.SearchInts(members, i+1):] {
+				if compared >= minHashMaxCompare {
+					break
+				}
+				if assigned[j] || seen[j] {
+					continue
+				}
+				seen[j] = true
+				compared++
+				sim := item.Signature.Similarity(items[j].Signature)
+				if sim < threshold {
+					continue
+				}
+				assigned[j] = true
+				matches[i] = append(matches[i], DedupMatch{Index: j, Similarity: sim})
+				// Exact copies of j follow it into this cluster.
+				for _, m := range matches[j] {
+					matches[i] = append(matches[i], DedupMatch{Index: m.Index, Similarity: sim})
+				}
+				delete(matches, j)
+			}
+		}
+	}
+
+	var clusters []DedupCluster
+	for i := range items {
+		if dups, ok := matches[i]; ok && !assigned[i] {
+			sort.Slice(dups, func(a, b int) bool { return dups[a].Index < dups[b].Index })
+			clusters = append(clusters, DedupCluster{Keep: i, Duplicates: dups})
+		}
+	}
+	return clusters
+}
+
+func bandKey(band int, rows MinHashSignature) uint64 {
+	key := splitMix64(uint64(band))
+	for _, v := range rows {
+		key = splitMix64(key ^ uint64(v))
+	}
+	return key
+}
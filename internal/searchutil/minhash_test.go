@@ -0,0 +1,78 @@
+package searchutil
+
+import (
+	"strings"
+	"testing"
+)
+
+func dedupItem(text string) DedupItem {
+	normalized := NormalizeForDedup(text)
+	return DedupItem{Fingerprint: DedupFingerprint(normalized), Signature: NewMinHashSignature(normalized)}
+}
+
+func TestNormalizeForDedup(t *testing.T) {
+	if got := NormalizeForDedup("Hello,  World!\n你好。"); got != "helloworld你好" {
+		t.Errorf("NormalizeForDedup = %q", got)
+	}
+}
+
+func TestMinHashSimilarity(t *testing.T) {
+	base := "知识库中的文档在上传后会经过解析、清洗与切分，生成若干分块；每个分块会调用嵌入模型计算向量，" +
+		"并连同关键词索引一起写入检索引擎。检索时系统同时执行向量召回与关键词召回，再经过重排序模型挑选最相关的片段，" +
+		"最终把这些片段连同用户问题一起交给大模型生成回答。"
+	near := NewMinHashSignature(NormalizeForDedup(base + "最后一句略有不同。"))
+	far := NewMinHashSignature(NormalizeForDedup("The quick brown fox jumps over the lazy dog near the river bank."))
+	sig := NewMinHashSignature(NormalizeForDedup(base))
+
+	if s := sig.Similarity(sig); s != 1 {
+		t.Errorf("self similarity = %v", s)
+	}
+	if s := sig.Similarity(near); s < 0.8 {
+		t.Errorf("near similarity = %v, want >= 0.8", s)
+	}
+	if s := sig.Similarity(far); s > 0.1 {
+		t.Errorf("unrelated similarity = %v, want <= 0.1", s)
+	}
+}
+
+func TestMinHashMerge(t *testing.T) {
+	a := NormalizeForDedup("first part of the document text")
+	b := NormalizeForDedup("second part with other words")
+	merged := NewMinHashSignature(a)
+	merged.Merge(NewMinHashSignature(b))
+	if s := merged.Similarity(NewMinHashSignature(a)); s >= 1 || s <= 0 {
+		t.Errorf("merged vs part similarity = %v, want strictly between 0 and 1", s)
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	base := "Uploaded documents are parsed, cleaned and split into chunks. Every chunk is embedded by the " +
+		"configured model and written to the retrieve engine together with its keyword index. At query time " +
+		"vector and keyword recall run side by side, a rerank model picks the most relevant passages, and " +
+		"those passages are handed to the chat model along with the question. "
+	items := []DedupItem{
+		dedupItem(base), // 0 kept
+		dedupItem("An unrelated paragraph about the weather in spring and autumn."), // 1
+		dedupItem(strings.ToUpper(base)),                                            // 2 exact copy of 0 after normalization
+		dedupItem(base + "One extra closing sentence."),                             // 3 near copy of 0
+		dedupItem(base + "One extra closing sentence."),                             // 4 exact copy of 3
+		{Fingerprint: "", Signature: nil},                                           // 5 ignored
+	}
+	clusters := FindDuplicates(items, 0.8)
+	if len(clusters) != 1 {
+		t.Fatalf("got %d clusters, want 1: %+v", len(clusters), clusters)
+	}
+	c := clusters[0]
+	if c.Keep != 0 || len(c.Duplicates) != 3 {
+		t.Fatalf("cluster = %+v", c)
+	}
+	if c.Duplicates[0].Index != 2 || !c.Duplicates[0].Exact {
+		t.Errorf("duplicate 0 = %+v, want exact item 2", c.Duplicates[0])
+	}
+	if c.Duplicates[1].Index != 3 || c.Duplicates[1].Exact || c.Duplicates[1].Similarity < 0.8 {
+		t.Errorf("duplicate 1 = %+v, want near item 3", c.Duplicates[1])
+	}
+	if c.Duplicates[2].Index != 4 {
+		t.Errorf("duplicate 2 = %+v, want item 4 following its exact copy", c.Duplicates[2])
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"strconv"
 
+	"github.com/Tencent/WeKnora/internal/tracing/telemetry"
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/hibiken/asynq"
 )
@@ -17,6 +18,9 @@ import (
 // present on ctx, it writes a zero-valued TracingContext — which round-trips
 // through JSON as absent fields and therefore costs nothing.
 //
+// It also stamps the W3C traceparent of the current OpenTelemetry span, if
+// any, so telemetry.AsynqMiddleware can continue that trace in the worker.
+//
 // Call sites live at every asynq.NewTask creation point. We deliberately
 // keep the API synchronous and mutating (rather than returning a new payload
 // copy) so that existing enqueue code needs only a single added line just
@@ -25,19 +29,19 @@ func InjectTracing(ctx context.Context, carrier types.LangfuseTracingCarrier) {
 	if carrier == nil {
 		return
 	}
-	mgr := GetManager()
-	if !mgr.Enabled() {
+	tc := types.TracingContext{TraceParent: telemetry.TraceParent(ctx)}
+	if mgr := GetManager(); mgr.Enabled() {
+		if trace, ok := TraceFromContext(ctx); ok && trace != nil {
+			tc.LangfuseTraceID = trace.ID
+		}
+		if obs, ok := parentObservationFromCtx(ctx); ok {
+			tc.LangfuseParentObservationID = obs
+		}
+		tc.LangfuseUserID = userIDFromCtx(ctx)
+		tc.LangfuseSessionID = sessionIDFromCtx(ctx)
+	} else if tc.TraceParent == "" {
 		return
 	}
-	tc := types.TracingContext{}
-	if trace, ok := TraceFromContext(ctx); ok && trace != nil {
-		tc.LangfuseTraceID = trace.ID
-	}
-	if obs, ok := parentObservationFromCtx(ctx); ok {
-		tc.LangfuseParentObservationID = obs
-	}
-	tc.LangfuseUserID = userIDFromCtx(ctx)
-	tc.LangfuseSessionID = sessionIDFromCtx(ctx)
 	carrier.SetLangfuseTracing(tc)
 }
 
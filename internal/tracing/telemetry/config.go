@@ -0,0 +1,88 @@
+// Package telemetry exports OpenTelemetry traces of the chat pipeline,
+// retriever repositories, file services and model providers over OTLP, so a
+// slow turn can be followed across subsystems in Jaeger, Tempo or any other
+// OTLP backend.
+//
+// Like the langfuse package it is opt-in: until Init installs an exporter
+// the global tracer provider is OpenTelemetry's no-op one, so Start and End
+// cost next to nothing and callers wire them unconditionally.
+package telemetry
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Supported values of Config.Protocol.
+const (
+	ProtocolGRPC = "grpc"
+	ProtocolHTTP = "http/protobuf"
+)
+
+// Config holds the exporter settings WeKnora reads itself. Everything else —
+// the collector endpoint, headers, TLS, the sampler and extra resource
+// attributes — follows the standard OTEL_* variables, which the SDK and the
+// OTLP exporters read directly.
+type Config struct {
+	// Enabled is the master switch. If false no spans are exported.
+	Enabled bool
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string
+	// Environment is reported as deployment.environment.name when set.
+	Environment string
+	// Protocol selects the OTLP transport: ProtocolGRPC or ProtocolHTTP.
+	Protocol string
+}
+
+// LoadConfigFromEnv builds a Config from the environment. Tracing turns on
+// when OTEL_TRACING_ENABLED is true, or when it is unset and an OTLP
+// endpoint is configured.
+func LoadConfigFromEnv() Config {
+	cfg := Config{
+		ServiceName: firstNonEmpty(os.Getenv("OTEL_SERVICE_NAME"), "weknora"),
+		Environment: strings.TrimSpace(os.Getenv("OTEL_DEPLOYMENT_ENVIRONMENT")),
+		Protocol: strings.ToLower(firstNonEmpty(
+			os.Getenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL"),
+			os.Getenv("OTEL_EXPORTER_OTLP_PROTOCOL"),
+			ProtocolGRPC,
+		)),
+	}
+	if v := strings.TrimSpace(os.Getenv("OTEL_TRACING_ENABLED")); v != "" {
+		cfg.Enabled, _ = strconv.ParseBool(v)
+	} else {
+		cfg.Enabled = firstNonEmpty(
+			os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"),
+			os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		) != ""
+	}
+	return cfg
+}
+
+// Validate reports configuration errors that would stop the exporter from
+// being built.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.ServiceName == "" {
+		return fmt.Errorf("telemetry: service name is required")
+	}
+	switch c.Protocol {
+	case ProtocolGRPC, ProtocolHTTP:
+		return nil
+	default:
+		return fmt.Errorf("telemetry: unsupported OTLP protocol %q (want %q or %q)",
+			c.Protocol, ProtocolGRPC, ProtocolHTTP)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
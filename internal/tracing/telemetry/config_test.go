@@ -0,0 +1,37 @@
+package telemetry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadConfigFromEnv(t *testing.T) {
+	t.Setenv("OTEL_TRACING_ENABLED", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "")
+	t.Setenv("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "")
+	t.Setenv("OTEL_SERVICE_NAME", "")
+
+	cfg := LoadConfigFromEnv()
+	assert.False(t, cfg.Enabled)
+	assert.Equal(t, "weknora", cfg.ServiceName)
+	assert.Equal(t, ProtocolGRPC, cfg.Protocol)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://tempo:4318")
+	t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/protobuf")
+	cfg = LoadConfigFromEnv()
+	assert.True(t, cfg.Enabled, "an endpoint turns tracing on")
+	assert.Equal(t, ProtocolHTTP, cfg.Protocol)
+
+	t.Setenv("OTEL_TRACING_ENABLED", "false")
+	assert.False(t, LoadConfigFromEnv().Enabled, "the explicit switch wins")
+}
+
+func TestConfigValidate(t *testing.T) {
+	assert.NoError(t, Config{}.Validate(), "a disabled config is always valid")
+	assert.NoError(t, Config{Enabled: true, ServiceName: "weknora", Protocol: ProtocolGRPC}.Validate())
+	assert.Error(t, Config{Enabled: true, ServiceName: "weknora", Protocol: "http/json"}.Validate())
+	assert.Error(t, Config{Enabled: true, Protocol: ProtocolGRPC}.Validate())
+}
@@ -0,0 +1,87 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// untracedPaths are routes polled often enough that their spans would only
+// bury the interesting ones.
+var untracedPaths = map[string]bool{
+	"/health":  true,
+	"/metrics": true,
+}
+
+// GinMiddleware opens a server span for every routed request, continuing the
+// caller's trace when the request carries a traceparent header. Register it
+// after middleware.RequestID: the IDs the auth middleware adds later are
+// attached once the handler chain returns.
+func GinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if !Enabled() || route == "" || untracedPaths[route] {
+			c.Next()
+			return
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(),
+			propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := start(ctx, c.Request.Method+" "+route, trace.SpanKindServer, []attribute.KeyValue{
+			attribute.String("http.request.method", c.Request.Method),
+			attribute.String("http.route", route),
+		})
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		span.SetAttributes(contextAttributes(c.Request.Context())...)
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// AsynqMiddleware opens a consumer span around every task. Tasks enqueued
+// with langfuse.InjectTracing carry the enqueuing span's traceparent, so the
+// worker side lands in the same trace as the HTTP request that caused it;
+// scheduled tasks start a trace of their own.
+func AsynqMiddleware() asynq.MiddlewareFunc {
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+			if !Enabled() {
+				return next.ProcessTask(ctx, task)
+			}
+
+			var tc types.TracingContext
+			_ = json.Unmarshal(task.Payload(), &tc)
+			ctx = ContextWithTraceParent(ctx, tc.TraceParent)
+
+			taskID, _ := asynq.GetTaskID(ctx)
+			retryCount, _ := asynq.GetRetryCount(ctx)
+			queueName, _ := asynq.GetQueueName(ctx)
+			ctx, span := start(ctx, "asynq."+task.Type(), trace.SpanKindConsumer, []attribute.KeyValue{
+				attribute.String("messaging.system", "asynq"),
+				attribute.String("messaging.destination.name", queueName),
+				attribute.String("messaging.message.id", taskID),
+				attribute.String("asynq.task_type", task.Type()),
+				attribute.Int("asynq.retry", retryCount),
+			})
+			err := next.ProcessTask(ctx, task)
+			End(span, err)
+			return err
+		})
+	}
+}
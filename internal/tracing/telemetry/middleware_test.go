@@ -0,0 +1,82 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestInitAndShutdown(t *testing.T) {
+	prevProvider, prevPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prevProvider)
+		otel.SetTextMapPropagator(prevPropagator)
+	})
+
+	disabled, err := Init(context.Background(), Config{}, nil)
+	require.NoError(t, err)
+	assert.False(t, Enabled())
+	assert.NoError(t, disabled.Shutdown(context.Background()))
+
+	_, err = Init(context.Background(), Config{Enabled: true, ServiceName: "weknora", Protocol: ProtocolGRPC}, nil)
+	assert.Error(t, err, "enabled without an exporter")
+
+	p, err := Init(context.Background(),
+		Config{Enabled: true, ServiceName: "weknora", Protocol: ProtocolGRPC}, tracetest.NewInMemoryExporter())
+	require.NoError(t, err)
+	assert.True(t, Enabled())
+	assert.NotEmpty(t, otel.GetTextMapPropagator().Fields(), "the W3C propagator is installed")
+
+	require.NoError(t, p.Shutdown(context.Background()))
+	assert.False(t, Enabled())
+}
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := installRecorder(t)
+	prevPropagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	enabled.Store(true)
+	t.Cleanup(func() {
+		otel.SetTextMapPropagator(prevPropagator)
+		enabled.Store(false)
+	})
+
+	r := gin.New()
+	r.Use(GinMiddleware())
+	r.Use(func(c *gin.Context) {
+		// Stands in for the auth middleware adding the tenant later on.
+		ctx := context.WithValue(c.Request.Context(), types.TenantIDContextKey, uint64(7))
+		c.Request = c.Request.WithContext(ctx)
+	})
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/sessions/:id", func(c *gin.Context) { c.Status(http.StatusBadGateway) })
+
+	for _, path := range []string{"/health", "/sessions/abc", "/unrouted"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1, "health checks and unrouted paths are not traced")
+	span := spans[0]
+	assert.Equal(t, "GET /sessions/:id", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, codes.Error, span.Status().Code)
+	assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", http.StatusBadGateway))
+	assert.Contains(t, span.Attributes(), AttrTenantID.String("7"))
+}
@@ -0,0 +1,79 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/Tencent/WeKnora/internal/logger"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+// Provider owns the SDK tracer provider installed by Init. A nil or
+// disabled Provider is valid and Shutdown on it is a no-op.
+type Provider struct {
+	tp *sdktrace.TracerProvider
+}
+
+var enabled atomic.Bool
+
+// Init installs a tracer provider batching spans to exporter as the global
+// one, together with the W3C trace context propagator. When cfg.Enabled is
+// false it returns a disabled Provider and leaves the no-op globals in
+// place; exporter may then be nil.
+//
+// The OTLP exporter is built by the caller (see container.initTelemetry) so
+// this package, which the model and repository layers import, stays free of
+// the gRPC and HTTP exporter dependencies.
+func Init(ctx context.Context, cfg Config, exporter sdktrace.SpanExporter) (*Provider, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return &Provider{}, nil
+	}
+	if exporter == nil {
+		return nil, fmt.Errorf("telemetry: tracing is enabled but no exporter was given")
+	}
+
+	attrs := []attribute.KeyValue{semconv.ServiceName(cfg.ServiceName)}
+	if cfg.Environment != "" {
+		attrs = append(attrs, semconv.DeploymentEnvironmentName(cfg.Environment))
+	}
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attrs...))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+	enabled.Store(true)
+
+	logger.Infof(ctx, "[OTel] tracing enabled service=%s protocol=%s", cfg.ServiceName, cfg.Protocol)
+	return &Provider{tp: tp}, nil
+}
+
+// Enabled reports whether spans are exported.
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Shutdown flushes the spans still queued and stops the exporter.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	if p == nil || p.tp == nil {
+		return nil
+	}
+	enabled.Store(false)
+	return p.tp.Shutdown(ctx)
+}
@@ -0,0 +1,85 @@
+package telemetry
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer every WeKnora span comes from.
+const instrumentationName = "github.com/Tencent/WeKnora"
+
+// Attribute keys shared by WeKnora spans. The IDs let a trace be found from
+// a request log line or a chat session.
+const (
+	AttrRequestID = attribute.Key("weknora.request_id")
+	AttrSessionID = attribute.Key("weknora.session_id")
+	AttrTenantID  = attribute.Key("weknora.tenant_id")
+)
+
+// traceParentKey is the W3C header that carries the span context.
+const traceParentKey = "traceparent"
+
+// Start opens a span named name as a child of the span in ctx. The request,
+// session and tenant IDs found on ctx are attached to it. Callers must end
+// the span, usually with End.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return start(ctx, name, trace.SpanKindInternal, attrs)
+}
+
+func start(ctx context.Context, name string, kind trace.SpanKind, attrs []attribute.KeyValue,
+) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, name,
+		trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+	if span.IsRecording() {
+		span.SetAttributes(contextAttributes(ctx)...)
+	}
+	return ctx, span
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TraceParent returns the W3C traceparent of the span in ctx, or "" when
+// there is none. It is how span context crosses the asynq boundary.
+func TraceParent(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+	return carrier.Get(traceParentKey)
+}
+
+// ContextWithTraceParent returns ctx with the remote span context encoded in
+// traceParent, so spans started from it join that trace. An empty or
+// malformed traceParent leaves ctx unchanged.
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{traceParentKey: traceParent})
+}
+
+func contextAttributes(ctx context.Context) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if id, ok := types.RequestIDFromContext(ctx); ok {
+		attrs = append(attrs, AttrRequestID.String(id))
+	}
+	if id, ok := types.SessionIDFromContext(ctx); ok {
+		attrs = append(attrs, AttrSessionID.String(id))
+	}
+	if id, ok := types.TenantIDFromContext(ctx); ok && id != 0 {
+		attrs = append(attrs, AttrTenantID.String(strconv.FormatUint(id, 10)))
+	}
+	return attrs
+}
@@ -0,0 +1,74 @@
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func installRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(prev) })
+	return recorder
+}
+
+func TestStartAttachesContextIDs(t *testing.T) {
+	recorder := installRecorder(t)
+
+	ctx := context.WithValue(context.Background(), types.RequestIDContextKey, "req-1")
+	ctx = context.WithValue(ctx, types.SessionIDContextKey, "sess-1")
+	ctx = context.WithValue(ctx, types.TenantIDContextKey, uint64(42))
+	_, span := Start(ctx, "pipeline.test", attribute.String("k", "v"))
+	End(span, errors.New("boom"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	got := map[attribute.Key]string{}
+	for _, kv := range spans[0].Attributes() {
+		got[kv.Key] = kv.Value.Emit()
+	}
+	assert.Equal(t, "req-1", got[AttrRequestID])
+	assert.Equal(t, "sess-1", got[AttrSessionID])
+	assert.Equal(t, "42", got[AttrTenantID])
+	assert.Equal(t, "v", got["k"])
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "boom", spans[0].Status().Description)
+}
+
+func TestTraceParentRoundTrip(t *testing.T) {
+	recorder := installRecorder(t)
+
+	assert.Empty(t, TraceParent(context.Background()))
+
+	ctx, parent := Start(context.Background(), "http")
+	traceParent := TraceParent(ctx)
+	require.NotEmpty(t, traceParent)
+	parent.End()
+
+	_, child := Start(ContextWithTraceParent(context.Background(), traceParent), "asynq.task")
+	End(child, nil)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, spans[0].SpanContext().TraceID(), spans[1].SpanContext().TraceID())
+	assert.Equal(t, spans[0].SpanContext().SpanID(), spans[1].Parent().SpanID())
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
+
+func TestContextWithTraceParentIgnoresGarbage(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, ctx, ContextWithTraceParent(ctx, ""))
+	assert.Empty(t, TraceParent(ContextWithTraceParent(ctx, "not-a-traceparent")))
+}
@@ -52,6 +52,10 @@ type AgentConfig struct {
 	PinnedSkillNames    []string `json:"-"`
 	// LLM call timeout in seconds (default: 120). Controls the maximum time for a single LLM call.
 	LLMCallTimeout int `json:"llm_call_timeout,omitempty"`
+	// Timeout in seconds for the whole ReAct loop (default: 0, no limit).
+	// Checked between rounds; once reached the agent stops calling tools and
+	// answers from the results gathered so far.
+	RunTimeout int `json:"run_timeout,omitempty"`
 
 	// Maximum character length for tool output (default: 16000).
 	// Outputs exceeding this limit are truncated with head + tail preservation.
@@ -0,0 +1,299 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxQueryLogQueryLength is the maximum length in runes of the question
+	// kept in a query log
+	MaxQueryLogQueryLength = 1000
+	// DefaultAnalyticsLimit is how many entries a ranking returns without a limit
+	DefaultAnalyticsLimit = 20
+	// MaxAnalyticsLimit bounds the entries of a ranking
+	MaxAnalyticsLimit = 100
+)
+
+// QueryLogMode is the chat mode a query was answered in
+type QueryLogMode string
+
+const (
+	QueryLogModeQuick QueryLogMode = "quick"
+	QueryLogModeAgent QueryLogMode = "agent"
+)
+
+// AnalyticsLatencyPercentiles are the answer latency percentiles the
+// analytics overview reports
+var AnalyticsLatencyPercentiles = []int{50, 90, 95, 99}
+
+// QueryLog records one answered chat query for analytics. Like feedback it
+// snapshots what it needs, so the aggregates survive re-chunking; it is
+// purged with its session by the retention policy.
+type QueryLog struct {
+	ID        string       `json:"id"         gorm:"type:varchar(36);primaryKey"`
+	TenantID  uint64       `json:"tenant_id"  gorm:"index"`
+	SessionID string       `json:"session_id" gorm:"type:varchar(36);index"`
+	MessageID string       `json:"message_id" gorm:"type:varchar(36);index"`
+	UserID    string       `json:"user_id"    gorm:"type:varchar(36)"`
+	Channel   string       `json:"channel"    gorm:"type:varchar(50)"`
+	Mode      QueryLogMode `json:"mode"       gorm:"type:varchar(16)"`
+	Query     string       `json:"query"      gorm:"type:text"`
+	// QueryHash identifies the normalized query, so that the same question
+	// asked with different case, punctuation or spacing is counted together
+	QueryHash string `json:"-"   gorm:"type:varchar(64);index"`
+	// Day in UTC the query was answered, formatted as TokenUsageDayLayout
+	Day string `json:"day" gorm:"type:varchar(10);index"`
+	// Searched is whether the query searched any knowledge
+	Searched bool `json:"searched"`
+	// Retrieved is the number of knowledge chunks the answer was given
+	Retrieved int `json:"retrieved"`
+	// ZeroResult marks a query that searched knowledge but retrieved no
+	// chunk above the retrieval thresholds
+	ZeroResult bool `json:"zero_result"`
+	// LatencyMs is the time from receiving the query to completing the answer
+	LatencyMs int64     `json:"latency_ms"`
+	CreatedAt time.Time `json:"created_at"`
+
+	KnowledgeBases []*QueryLogKnowledgeBase `json:"-" gorm:"-"`
+	Documents      []*QueryLogDocument      `json:"-" gorm:"-"`
+}
+
+// TableName returns the table name of QueryLog
+func (QueryLog) TableName() string {
+	return "query_logs"
+}
+
+// QueryLogKnowledgeBase is a knowledge base a query searched or retrieved from
+type QueryLogKnowledgeBase struct {
+	QueryLogID      string `gorm:"type:varchar(36);primaryKey"`
+	KnowledgeBaseID string `gorm:"type:varchar(36);primaryKey;index"`
+	TenantID        uint64 `gorm:"index"`
+	Day             string `gorm:"type:varchar(10)"`
+	ZeroResult      bool
+}
+
+// TableName returns the table name of QueryLogKnowledgeBase
+func (QueryLogKnowledgeBase) TableName() string {
+	return "query_log_knowledge_bases"
+}
+
+// QueryLogDocument is a document an answer retrieved chunks from. Cited is
+// whether the answer cites one of them inline.
+type QueryLogDocument struct {
+	QueryLogID      string `gorm:"type:varchar(36);primaryKey"`
+	KnowledgeID     string `gorm:"type:varchar(36);primaryKey;index"`
+	TenantID        uint64 `gorm:"index"`
+	KnowledgeBaseID string `gorm:"type:varchar(36)"`
+	KnowledgeTitle  string
+	Day             string `gorm:"type:varchar(10)"`
+	Chunks          int
+	Cited           bool
+}
+
+// TableName returns the table name of QueryLogDocument
+func (QueryLogDocument) TableName() string {
+	return "query_log_documents"
+}
+
+// QueryRecord is a completed answer to log for analytics
+type QueryRecord struct {
+	SessionID string
+	MessageID string
+	Channel   string
+	Mode      QueryLogMode
+	Query     string
+	// KnowledgeBaseIDs are the knowledge bases the request or its agent
+	// selected; KnowledgeIDs the documents the request named
+	KnowledgeBaseIDs []string
+	KnowledgeIDs     []string
+	// Searched is whether the request was set up to search knowledge. A
+	// query that retrieved knowledge anyway, like one of an agent searching
+	// on its own, counts as searched too.
+	Searched   bool
+	References References
+	Citations  Citations
+	ReceivedAt time.Time
+	AnsweredAt time.Time
+}
+
+// QueryHash returns the hash of a normalized query
+func QueryHash(query string) string {
+	sum := sha256.Sum256([]byte(NormalizeQueryText(query)))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewQueryLog builds the query log of a completed answer with the
+// knowledge bases it searched and the documents it retrieved. Web search
+// results are not knowledge and are left out.
+func NewQueryLog(tenantID uint64, userID string, r *QueryRecord) *QueryLog {
+	query := strings.TrimSpace(r.Query)
+	if runes := []rune(query); len(runes) > MaxQueryLogQueryLength {
+		query = string(runes[:MaxQueryLogQueryLength])
+	}
+	day := r.AnsweredAt.UTC().Format(TokenUsageDayLayout)
+	log := &QueryLog{
+		TenantID:  tenantID,
+		SessionID: r.SessionID,
+		MessageID: r.MessageID,
+		UserID:    userID,
+		Channel:   r.Channel,
+		Mode:      r.Mode,
+		Query:     query,
+		QueryHash: QueryHash(query),
+		Day:       day,
+		LatencyMs: r.AnsweredAt.Sub(r.ReceivedAt).Milliseconds(),
+	}
+	if log.LatencyMs < 0 {
+		log.LatencyMs = 0
+	}
+
+	cited := make(map[string]bool, len(r.Citations))
+	for _, c := range r.Citations {
+		cited[c.KnowledgeID] = true
+	}
+	documents := make(map[string]*QueryLogDocument)
+	knowledgeBases := make(map[string]bool)
+	addKnowledgeBase := func(id string) {
+		if id != "" && !knowledgeBases[id] {
+			knowledgeBases[id] = true
+			log.KnowledgeBases = append(log.KnowledgeBases, &QueryLogKnowledgeBase{
+				KnowledgeBaseID: id, TenantID: tenantID, Day: day,
+			})
+		}
+	}
+	for _, id := range r.KnowledgeBaseIDs {
+		addKnowledgeBase(id)
+	}
+	for _, ref := range r.References {
+		if ref == nil || ref.KnowledgeID == "" || ref.MatchType == MatchTypeWebSearch {
+			continue
+		}
+		log.Retrieved++
+		addKnowledgeBase(ref.KnowledgeBaseID)
+		doc := documents[ref.KnowledgeID]
+		if doc == nil {
+			doc = &QueryLogDocument{
+				KnowledgeID:     ref.KnowledgeID,
+				TenantID:        tenantID,
+				KnowledgeBaseID: ref.KnowledgeBaseID,
+				KnowledgeTitle:  ref.KnowledgeTitle,
+				Day:             day,
+				Cited:           cited[ref.KnowledgeID],
+			}
+			documents[ref.KnowledgeID] = doc
+			log.Documents = append(log.Documents, doc)
+		}
+		doc.Chunks++
+	}
+
+	log.Searched = r.Searched || len(r.KnowledgeBaseIDs) > 0 || len(r.KnowledgeIDs) > 0 || log.Retrieved > 0
+	log.ZeroResult = log.Searched && log.Retrieved == 0
+	for _, kb := range log.KnowledgeBases {
+		kb.ZeroResult = log.ZeroResult
+	}
+	return log
+}
+
+// AnalyticsQuery selects the query logs of a tenant. StartDay and EndDay
+// are inclusive and formatted as TokenUsageDayLayout; KnowledgeBaseID keeps
+// the queries that searched that knowledge base, and only its documents.
+type AnalyticsQuery struct {
+	TenantID        uint64
+	StartDay        string
+	EndDay          string
+	KnowledgeBaseID string
+	Limit           int
+}
+
+// QueryDayCounts counts the queries answered on one day
+type QueryDayCounts struct {
+	Day          string `json:"day"`
+	Queries      int64  `json:"queries"`
+	Searched     int64  `json:"searched"`
+	ZeroResults  int64  `json:"zero_results"`
+	AvgLatencyMs int64  `json:"avg_latency_ms"`
+	// LatencySum adds up the latencies for the average of several days
+	LatencySum int64 `json:"-"`
+}
+
+// LatencyPercentile is the answer latency below which Percentile percent
+// of the queries completed
+type LatencyPercentile struct {
+	Percentile int   `json:"percentile"`
+	LatencyMs  int64 `json:"latency_ms"`
+}
+
+// QueryAnalyticsOverview sums up the queries of a tenant over a date range.
+// ZeroResultRate is the share of searching queries that retrieved nothing.
+type QueryAnalyticsOverview struct {
+	StartDate      string               `json:"start_date"`
+	EndDate        string               `json:"end_date"`
+	Queries        int64                `json:"queries"`
+	Searched       int64                `json:"searched"`
+	ZeroResults    int64                `json:"zero_results"`
+	ZeroResultRate float64              `json:"zero_result_rate"`
+	AvgLatencyMs   int64                `json:"avg_latency_ms"`
+	Latency        []*LatencyPercentile `json:"latency_percentiles"`
+	Days           []*QueryDayCounts    `json:"days"`
+}
+
+// NewQueryAnalyticsOverview sums up the per-day counts
+func NewQueryAnalyticsOverview(startDay, endDay string, days []*QueryDayCounts) *QueryAnalyticsOverview {
+	o := &QueryAnalyticsOverview{StartDate: startDay, EndDate: endDay, Days: days}
+	var latencySum int64
+	for _, day := range days {
+		o.Queries += day.Queries
+		o.Searched += day.Searched
+		o.ZeroResults += day.ZeroResults
+		latencySum += day.LatencySum
+		if day.Queries > 0 {
+			day.AvgLatencyMs = day.LatencySum / day.Queries
+		}
+	}
+	if o.Queries > 0 {
+		o.AvgLatencyMs = latencySum / o.Queries
+	}
+	if o.Searched > 0 {
+		o.ZeroResultRate = float64(o.ZeroResults) / float64(o.Searched)
+	}
+	return o
+}
+
+// QueryStat is how often a query was asked. Query is one of its phrasings.
+type QueryStat struct {
+	Query        string `json:"query"`
+	Count        int64  `json:"count"`
+	ZeroResults  int64  `json:"zero_results"`
+	AvgLatencyMs int64  `json:"avg_latency_ms"`
+	LastDay      string `json:"last_day"`
+}
+
+// DocumentStat is how often answers retrieved and cited a document
+type DocumentStat struct {
+	KnowledgeID     string `json:"knowledge_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	KnowledgeTitle  string `json:"knowledge_title"`
+	// Citations counts the answers citing the document inline, Retrievals
+	// the answers given any of its chunks
+	Citations  int64 `json:"citations"`
+	Retrievals int64 `json:"retrievals"`
+}
+
+// KnowledgeBaseDayCounts counts the queries that searched a knowledge base on one day
+type KnowledgeBaseDayCounts struct {
+	Day         string `json:"day"`
+	Queries     int64  `json:"queries"`
+	ZeroResults int64  `json:"zero_results"`
+}
+
+// KnowledgeBaseUsage is how a knowledge base was searched over a date range
+type KnowledgeBaseUsage struct {
+	KnowledgeBaseID string                    `json:"knowledge_base_id"`
+	Name            string                    `json:"name"`
+	Queries         int64                     `json:"queries"`
+	ZeroResults     int64                     `json:"zero_results"`
+	Days            []*KnowledgeBaseDayCounts `json:"days"`
+}
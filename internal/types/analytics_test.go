@@ -0,0 +1,65 @@
+package types
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewQueryLog(t *testing.T) {
+	received := time.Date(2026, 10, 16, 23, 59, 59, 0, time.FixedZone("CST", 8*3600))
+	log := NewQueryLog(7, "u1", &QueryRecord{
+		SessionID:        "s1",
+		MessageID:        "m1",
+		Mode:             QueryLogModeQuick,
+		Query:            "  年假有几天？ ",
+		KnowledgeBaseIDs: []string{"kb1"},
+		References: References{
+			{ID: "c1", KnowledgeID: "k1", KnowledgeBaseID: "kb1", KnowledgeTitle: "手册"},
+			{ID: "c2", KnowledgeID: "k1", KnowledgeBaseID: "kb1", KnowledgeTitle: "手册"},
+			{ID: "c3", KnowledgeID: "k2", KnowledgeBaseID: "kb2", KnowledgeTitle: "制度"},
+			{ID: "w1", KnowledgeID: "web", MatchType: MatchTypeWebSearch},
+			nil,
+		},
+		Citations:  Citations{{Index: 1, ChunkID: "c3", KnowledgeID: "k2"}},
+		ReceivedAt: received,
+		AnsweredAt: received.Add(1500 * time.Millisecond),
+	})
+
+	assert.Equal(t, "年假有几天？", log.Query)
+	assert.Equal(t, QueryHash("年假有几天"), log.QueryHash, "punctuation does not split queries")
+	assert.Equal(t, "2026-10-16", log.Day, "days are in UTC")
+	assert.Equal(t, int64(1500), log.LatencyMs)
+	assert.Equal(t, 3, log.Retrieved, "web search results are not knowledge")
+	assert.True(t, log.Searched)
+	assert.False(t, log.ZeroResult)
+
+	require.Len(t, log.KnowledgeBases, 2)
+	assert.Equal(t, "kb1", log.KnowledgeBases[0].KnowledgeBaseID)
+	assert.Equal(t, "kb2", log.KnowledgeBases[1].KnowledgeBaseID)
+	require.Len(t, log.Documents, 2)
+	assert.Equal(t, 2, log.Documents[0].Chunks)
+	assert.False(t, log.Documents[0].Cited)
+	assert.True(t, log.Documents[1].Cited)
+}
+
+func TestNewQueryLog_ZeroResult(t *testing.T) {
+	now := time.Now()
+	searched := NewQueryLog(1, "", &QueryRecord{
+		Query: strings.Repeat("问", MaxQueryLogQueryLength+10), KnowledgeIDs: []string{"k1"},
+		ReceivedAt: now, AnsweredAt: now,
+	})
+	assert.True(t, searched.ZeroResult)
+	assert.Len(t, []rune(searched.Query), MaxQueryLogQueryLength)
+
+	agent := NewQueryLog(1, "", &QueryRecord{Query: "hi", Searched: true, ReceivedAt: now, AnsweredAt: now})
+	assert.True(t, agent.ZeroResult)
+	assert.Empty(t, agent.KnowledgeBases)
+
+	chat := NewQueryLog(1, "", &QueryRecord{Query: "hi", ReceivedAt: now, AnsweredAt: now})
+	assert.False(t, chat.Searched)
+	assert.False(t, chat.ZeroResult, "queries that search nothing cannot miss")
+}
@@ -0,0 +1,96 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// AnswerVerificationAction is what the answer verifier does about claims
+// the retrieved context does not support
+type AnswerVerificationAction string
+
+const (
+	// AnswerVerificationDisclaimer appends a disclaimer listing the
+	// unsupported claims to the answer
+	AnswerVerificationDisclaimer AnswerVerificationAction = "disclaimer"
+	// AnswerVerificationRegenerate regenerates the answer once without the
+	// unsupported claims, and appends a disclaimer if some remain
+	AnswerVerificationRegenerate AnswerVerificationAction = "regenerate"
+)
+
+// AnswerVerdict summarizes how well the retrieved context supports an answer
+type AnswerVerdict string
+
+const (
+	AnswerVerdictSupported          AnswerVerdict = "supported"
+	AnswerVerdictPartiallySupported AnswerVerdict = "partially_supported"
+	AnswerVerdictUnsupported        AnswerVerdict = "unsupported"
+)
+
+// ClaimVerdict is the judgement of one claim of an answer
+type ClaimVerdict struct {
+	Claim     string `json:"claim"`
+	Supported bool   `json:"supported"`
+	// Reason explains the judgement, e.g. which passage contradicts the claim
+	Reason string `json:"reason,omitempty"`
+}
+
+// AnswerVerification is the outcome of checking an answer's claims against
+// the retrieved context it was generated from.
+type AnswerVerification struct {
+	Verdict AnswerVerdict  `json:"verdict"`
+	Claims  []ClaimVerdict `json:"claims"`
+	// Regenerated is set when the answer was replaced by a regenerated one;
+	// Claims then judge the regenerated answer
+	Regenerated bool `json:"regenerated,omitempty"`
+	// Disclaimer is the text appended to the answer, if any
+	Disclaimer string `json:"disclaimer,omitempty"`
+}
+
+// NewAnswerVerification builds a verification from claim verdicts. An
+// answer without factual claims is supported.
+func NewAnswerVerification(claims []ClaimVerdict) *AnswerVerification {
+	if claims == nil {
+		claims = []ClaimVerdict{}
+	}
+	v := &AnswerVerification{Verdict: AnswerVerdictSupported, Claims: claims}
+	if unsupported := len(v.UnsupportedClaims()); unsupported == len(claims) && unsupported > 0 {
+		v.Verdict = AnswerVerdictUnsupported
+	} else if unsupported > 0 {
+		v.Verdict = AnswerVerdictPartiallySupported
+	}
+	return v
+}
+
+// UnsupportedClaims returns the claims the context does not support
+func (v *AnswerVerification) UnsupportedClaims() []string {
+	var claims []string
+	for _, c := range v.Claims {
+		if !c.Supported {
+			claims = append(claims, c.Claim)
+		}
+	}
+	return claims
+}
+
+// Value implements the driver.Valuer interface for database serialization
+func (v AnswerVerification) Value() (driver.Value, error) {
+	return json.Marshal(v)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (v *AnswerVerification) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	var b []byte
+	switch val := value.(type) {
+	case []byte:
+		b = val
+	case string:
+		b = []byte(val)
+	default:
+		return nil
+	}
+	return json.Unmarshal(b, v)
+}
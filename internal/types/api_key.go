@@ -0,0 +1,129 @@
+package types
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeySecretPrefix starts the secret of every managed API key. It tells
+// managed keys apart from the legacy per-tenant key, which starts with sk-.
+const APIKeySecretPrefix = "wk-"
+
+// API key scopes. A key may only call the endpoints its scopes cover, and
+// within them only what its role allows.
+const (
+	// APIKeyScopeAll covers every endpoint
+	APIKeyScopeAll = "all"
+	// APIKeyScopeRead covers the read-only (GET) endpoints except tenant
+	// settings and API key management
+	APIKeyScopeRead = "read"
+	// APIKeyScopeChat covers sessions, chat, messages and knowledge search
+	APIKeyScopeChat = "chat"
+	// APIKeyScopeIngest covers uploading and maintaining documents and FAQ entries
+	APIKeyScopeIngest = "ingest"
+)
+
+// IsValidAPIKeyScope reports whether scope is a known API key scope.
+func IsValidAPIKeyScope(scope string) bool {
+	switch scope {
+	case APIKeyScopeAll, APIKeyScopeRead, APIKeyScopeChat, APIKeyScopeIngest:
+		return true
+	}
+	return false
+}
+
+// DefaultAPIKeyRole is the role of a key created without one: viewer when
+// the key only reads and chats, admin otherwise so ingest keys can write to
+// every knowledge base they are scoped to.
+func DefaultAPIKeyRole(scopes []string) TenantRole {
+	for _, scope := range scopes {
+		if scope != APIKeyScopeRead && scope != APIKeyScopeChat {
+			return TenantRoleAdmin
+		}
+	}
+	return TenantRoleViewer
+}
+
+// APIKey is a managed API key of a tenant. Only the SHA-256 of the secret is
+// stored; the secret is shown once, when the key is created or rotated.
+// Revoking a key soft-deletes it.
+type APIKey struct {
+	ID       string `json:"id"         gorm:"type:varchar(36);primaryKey"`
+	TenantID uint64 `json:"tenant_id"  gorm:"index"`
+	Name     string `json:"name"       gorm:"type:varchar(100)"`
+	// KeyPrefix is the start of the secret, shown to tell keys apart
+	KeyPrefix string `json:"key_prefix" gorm:"type:varchar(16)"`
+	// KeyHash is the hex SHA-256 of the secret
+	KeyHash string `json:"-"          gorm:"type:varchar(64);uniqueIndex"`
+	// Role is the tenant role requests made with the key act with
+	Role   TenantRole  `json:"role"   gorm:"type:varchar(32)"`
+	Scopes StringArray `json:"scopes" gorm:"type:json"`
+	// KnowledgeBaseIDs restricts the key to these knowledge bases; empty
+	// means every knowledge base the role can reach
+	KnowledgeBaseIDs StringArray `json:"knowledge_base_ids" gorm:"type:json"`
+	// ExpiresAt is nil for keys that never expire
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	// CreatedBy is the user who created the key
+	CreatedBy string         `json:"created_by" gorm:"type:varchar(36)"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-"          gorm:"index"`
+}
+
+// TableName returns the table name of APIKey
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsExpired reports whether the key has expired at now.
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// HasScope reports whether the key carries scope, or the all scope.
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, APIKeyScopeAll) || slices.Contains(k.Scopes, scope)
+}
+
+// AllowsKnowledgeBase reports whether the key may reach the knowledge base.
+func (k *APIKey) AllowsKnowledgeBase(kbID string) bool {
+	return len(k.KnowledgeBaseIDs) == 0 || slices.Contains(k.KnowledgeBaseIDs, kbID)
+}
+
+// HashAPIKeySecret returns the stored form of an API key secret.
+func HashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(secret)))
+	return hex.EncodeToString(sum[:])
+}
+
+// APIKeyFromContext returns the managed API key the request authenticated
+// with, if any. Requests made with the legacy tenant key carry none.
+func APIKeyFromContext(ctx context.Context) (*APIKey, bool) {
+	key, ok := ctx.Value(APIKeyContextKey).(*APIKey)
+	return key, ok && key != nil
+}
+
+// APIKeyRequest creates an API key or replaces its settings.
+type APIKeyRequest struct {
+	Name   string   `json:"name"   binding:"required,max=100"`
+	Scopes []string `json:"scopes" binding:"required"`
+	// Role defaults to DefaultAPIKeyRole(Scopes) when empty
+	Role             TenantRole `json:"role"`
+	KnowledgeBaseIDs []string   `json:"knowledge_base_ids"`
+	// ExpiresAt is nil for a key that never expires
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyWithSecret is an API key together with its secret, returned only
+// when the key is created or rotated.
+type APIKeyWithSecret struct {
+	*APIKey
+	Secret string `json:"secret"`
+}
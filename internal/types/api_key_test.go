@@ -0,0 +1,48 @@
+package types
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyExpiryScopesAndKnowledgeBases(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Hour)
+
+	assert.False(t, (&APIKey{}).IsExpired(now), "keys without expiry never expire")
+	assert.False(t, (&APIKey{ExpiresAt: &future}).IsExpired(now))
+	assert.True(t, (&APIKey{ExpiresAt: &past}).IsExpired(now))
+	assert.True(t, (&APIKey{ExpiresAt: &now}).IsExpired(now), "a key is expired at its expiry time")
+
+	chat := &APIKey{Scopes: StringArray{APIKeyScopeChat}}
+	assert.True(t, chat.HasScope(APIKeyScopeChat))
+	assert.False(t, chat.HasScope(APIKeyScopeIngest))
+	assert.True(t, (&APIKey{Scopes: StringArray{APIKeyScopeAll}}).HasScope(APIKeyScopeIngest))
+
+	assert.True(t, chat.AllowsKnowledgeBase("kb-1"), "an unrestricted key reaches every knowledge base")
+	restricted := &APIKey{KnowledgeBaseIDs: StringArray{"kb-1"}}
+	assert.True(t, restricted.AllowsKnowledgeBase("kb-1"))
+	assert.False(t, restricted.AllowsKnowledgeBase("kb-2"))
+}
+
+func TestDefaultAPIKeyRole(t *testing.T) {
+	assert.Equal(t, TenantRoleViewer, DefaultAPIKeyRole([]string{APIKeyScopeChat, APIKeyScopeRead}))
+	assert.Equal(t, TenantRoleAdmin, DefaultAPIKeyRole([]string{APIKeyScopeChat, APIKeyScopeIngest}))
+	assert.Equal(t, TenantRoleAdmin, DefaultAPIKeyRole([]string{APIKeyScopeAll}))
+}
+
+func TestHashAPIKeySecretAndContext(t *testing.T) {
+	assert.Equal(t, HashAPIKeySecret("wk-secret"), HashAPIKeySecret(" wk-secret\n"))
+	assert.NotEqual(t, HashAPIKeySecret("wk-secret"), HashAPIKeySecret("wk-other"))
+	assert.Len(t, HashAPIKeySecret("wk-secret"), 64)
+
+	_, ok := APIKeyFromContext(context.Background())
+	assert.False(t, ok)
+	key := &APIKey{ID: "k1"}
+	got, ok := APIKeyFromContext(context.WithValue(context.Background(), APIKeyContextKey, key))
+	assert.True(t, ok)
+	assert.Same(t, key, got)
+}
@@ -50,6 +50,15 @@ const (
 	// AuditActionInvitationExpired fires when the lazy sweep transitions
 	// an overdue pending row to expired. Actor is empty (system).
 	AuditActionInvitationExpired AuditAction = "rbac.invitation_expired"
+	// AuditActionAPIKeyRoleChanged fires when an Owner changes the role
+	// granted to the tenant API key. Details carries old_role and new_role.
+	AuditActionAPIKeyRoleChanged AuditAction = "rbac.api_key_role_changed"
+	// Managed API key lifecycle. TargetID is the key ID; created and
+	// updated carry the role, scopes and knowledge_base_ids in Details.
+	AuditActionAPIKeyCreated AuditAction = "rbac.api_key_created"
+	AuditActionAPIKeyUpdated AuditAction = "rbac.api_key_updated"
+	AuditActionAPIKeyRotated AuditAction = "rbac.api_key_rotated"
+	AuditActionAPIKeyRevoked AuditAction = "rbac.api_key_revoked"
 
 	// VectorStore lifecycle actions. Emitted by VectorStoreService.
 	// Cover both env-store-derived (__env_*) and DB store create /
@@ -116,6 +125,24 @@ const (
 	// reader can distinguish a real revoke from a noop attempt.
 	// TenantID=0 because the change is system-scope.
 	AuditActionSystemAdminRevoked AuditAction = "system.admin_revoked"
+
+	// AuditActionFileScanned fires for every upload checked by the
+	// malware scanner, whatever the verdict. Outcome is success when the
+	// file was accepted and denied when it was rejected or quarantined.
+	// TargetID is the knowledge the upload was created as (empty for
+	// rejected uploads). Details payload carries {file_name, file_size,
+	// knowledge_base_id, scanner, verdict, signature, action, error}.
+	AuditActionFileScanned AuditAction = "file.scanned"
+
+	// AuditActionRetentionPolicyUpdated fires when an Admin changes a
+	// retention policy. TargetID is the policy ID; Details carries the
+	// knowledge_base_id (empty for the tenant-wide policy) and the new
+	// settings.
+	AuditActionRetentionPolicyUpdated AuditAction = "retention.policy_updated"
+	// AuditActionRetentionPurged fires after every retention run that
+	// deleted data. Actor is empty (system); Details carries the run's
+	// RetentionReport with the cutoffs and how much was deleted.
+	AuditActionRetentionPurged AuditAction = "retention.purged"
 )
 
 // AuditOutcome distinguishes successful mutations from middleware-level
@@ -25,6 +25,11 @@ func TestAuditAction_DotNamespaceConvention(t *testing.T) {
 		AuditActionInvitationDeclined,
 		AuditActionInvitationRevoked,
 		AuditActionInvitationExpired,
+		AuditActionAPIKeyRoleChanged,
+		AuditActionAPIKeyCreated,
+		AuditActionAPIKeyUpdated,
+		AuditActionAPIKeyRotated,
+		AuditActionAPIKeyRevoked,
 		// VectorStore namespace (Phase 3 PR 1 / #1440)
 		AuditActionVectorStoreCreated,
 		AuditActionVectorStoreUpdated,
@@ -37,6 +42,9 @@ func TestAuditAction_DotNamespaceConvention(t *testing.T) {
 		AuditActionSystemSettingChanged,
 		AuditActionSystemAdminPromoted,
 		AuditActionSystemAdminRevoked,
+		// Retention namespace
+		AuditActionRetentionPolicyUpdated,
+		AuditActionRetentionPurged,
 	}
 	for _, a := range all {
 		s := string(a)
@@ -109,6 +117,11 @@ func TestAuditAction_NoCollisionsAcrossNamespaces(t *testing.T) {
 	register("AuditActionInvitationDeclined", AuditActionInvitationDeclined)
 	register("AuditActionInvitationRevoked", AuditActionInvitationRevoked)
 	register("AuditActionInvitationExpired", AuditActionInvitationExpired)
+	register("AuditActionAPIKeyRoleChanged", AuditActionAPIKeyRoleChanged)
+	register("AuditActionAPIKeyCreated", AuditActionAPIKeyCreated)
+	register("AuditActionAPIKeyUpdated", AuditActionAPIKeyUpdated)
+	register("AuditActionAPIKeyRotated", AuditActionAPIKeyRotated)
+	register("AuditActionAPIKeyRevoked", AuditActionAPIKeyRevoked)
 	register("AuditActionVectorStoreCreated", AuditActionVectorStoreCreated)
 	register("AuditActionVectorStoreUpdated", AuditActionVectorStoreUpdated)
 	register("AuditActionVectorStoreDeleted", AuditActionVectorStoreDeleted)
@@ -118,6 +131,8 @@ func TestAuditAction_NoCollisionsAcrossNamespaces(t *testing.T) {
 	register("AuditActionSystemSettingChanged", AuditActionSystemSettingChanged)
 	register("AuditActionSystemAdminPromoted", AuditActionSystemAdminPromoted)
 	register("AuditActionSystemAdminRevoked", AuditActionSystemAdminRevoked)
+	register("AuditActionRetentionPolicyUpdated", AuditActionRetentionPolicyUpdated)
+	register("AuditActionRetentionPurged", AuditActionRetentionPurged)
 }
 
 // TestAuditAction_SystemNamespacePrefix pins the three system.* actions
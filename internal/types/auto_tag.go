@@ -0,0 +1,83 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// Knowledge tag relation sources.
+const (
+	// KnowledgeTagSourceManual marks a tag attached by a user or an API call
+	KnowledgeTagSourceManual = "manual"
+	// KnowledgeTagSourceAuto marks a tag attached by auto-tagging
+	KnowledgeTagSourceAuto = "auto"
+)
+
+// Auto-tagging limits.
+const (
+	DefaultAutoTagMaxTags = 3
+	MaxAutoTagMaxTags     = 10
+)
+
+// AutoTagConfig controls automatic tagging of the documents of a knowledge
+// base. When enabled, every document is classified against the knowledge
+// base's tags once its summary has been generated. Auto-tagging only ever
+// replaces tags it attached itself; documents whose tags were set by hand
+// are left alone.
+type AutoTagConfig struct {
+	Enabled bool `yaml:"enabled"         json:"enabled"`
+	// MaxTags caps the tags attached to one document (default: 3, max: 10)
+	MaxTags int `yaml:"max_tags"        json:"max_tags"`
+	// Chat model used to classify documents; empty uses the knowledge base's
+	// summary model
+	ModelID string `yaml:"model_id"        json:"model_id,omitempty"`
+	// RetagOnChange re-tags the knowledge base's documents shortly after a
+	// tag is created, renamed or deleted
+	RetagOnChange bool `yaml:"retag_on_change" json:"retag_on_change"`
+}
+
+// GetMaxTags returns MaxTags with the default and upper bound applied.
+func (c *AutoTagConfig) GetMaxTags() int {
+	if c == nil || c.MaxTags <= 0 {
+		return DefaultAutoTagMaxTags
+	}
+	if c.MaxTags > MaxAutoTagMaxTags {
+		return MaxAutoTagMaxTags
+	}
+	return c.MaxTags
+}
+
+// Value implements the driver.Valuer interface
+func (c AutoTagConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface
+func (c *AutoTagConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
+
+// AutoTagRequest is the body of a re-tagging request.
+type AutoTagRequest struct {
+	// KnowledgeIDs limits re-tagging to these documents; empty re-tags every
+	// document of the knowledge base
+	KnowledgeIDs []string `json:"knowledge_ids"`
+}
+
+// AutoTagPayload is the knowledge:auto_tag task payload.
+type AutoTagPayload struct {
+	TracingContext
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	// KnowledgeIDs are the documents to tag; empty tags every document of
+	// the knowledge base
+	KnowledgeIDs []string `json:"knowledge_ids,omitempty"`
+	Language     string   `json:"language,omitempty"`
+}
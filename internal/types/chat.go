@@ -82,6 +82,14 @@ const (
 	ResponseTypeAnswer ResponseType = "answer"
 	// References response type
 	ResponseTypeReferences ResponseType = "references"
+	// Citations response type (sources cited inline in the answer)
+	ResponseTypeCitations ResponseType = "citations"
+	// Verification response type (answer claims checked against the context)
+	ResponseTypeVerification ResponseType = "verification"
+	// Follow-ups response type (suggested follow-up questions)
+	ResponseTypeFollowUps ResponseType = "follow_ups"
+	// Debug trace response type (per-stage trace of a debug-traced run)
+	ResponseTypeDebugTrace ResponseType = "debug_trace"
 	// Thinking response type (for agent thought process)
 	ResponseTypeThinking ResponseType = "thinking"
 	// Tool call response type (for agent tool invocations)
@@ -118,6 +126,10 @@ type StreamResponse struct {
 	Content             string                 `json:"content"`
 	Done                bool                   `json:"done"`
 	KnowledgeReferences References             `json:"knowledge_references,omitempty"`
+	Citations           Citations              `json:"citations,omitempty"`
+	Verification        *AnswerVerification    `json:"verification,omitempty"`
+	FollowUpQuestions   []string               `json:"follow_up_questions,omitempty"`
+	DebugTrace          *PipelineTrace         `json:"debug_trace,omitempty"`
 	SessionID           string                 `json:"session_id,omitempty"`
 	AssistantMessageID  string                 `json:"assistant_message_id,omitempty"`
 	ToolCalls           []LLMToolCall          `json:"tool_calls,omitempty"`
@@ -9,6 +9,10 @@ type PipelineRequest struct {
 	Query        string `json:"query,omitempty"`
 	EnableMemory bool   `json:"enable_memory"`
 	MaxRounds    int    `json:"max_rounds"`
+	// MemoryPolicy is the session's policy for storing turns in memory;
+	// Remember stores this turn whatever the policy says.
+	MemoryPolicy *MemoryPolicy `json:"memory_policy,omitempty"`
+	Remember     bool          `json:"remember,omitempty"`
 
 	// Knowledge base retrieval parameters
 	KnowledgeBaseIDs []string      `json:"knowledge_base_ids"`
@@ -23,6 +27,8 @@ type PipelineRequest struct {
 	RerankModelID   string  `json:"rerank_model_id"`
 	RerankTopK      int     `json:"rerank_top_k"`
 	RerankThreshold float64 `json:"rerank_threshold"`
+	// RerankFallbackModelIDs are tried in order when the rerank model fails
+	RerankFallbackModelIDs []string `json:"rerank_fallback_model_ids,omitempty"`
 
 	// Chat model parameters
 	ChatModelID      string           `json:"chat_model_id"`
@@ -30,6 +36,31 @@ type PipelineRequest struct {
 	FallbackStrategy FallbackStrategy `json:"fallback_strategy"`
 	FallbackResponse string           `json:"fallback_response"`
 	FallbackPrompt   string           `json:"fallback_prompt"`
+	// ChatFallbackModelIDs are tried in order when the chat model is rate
+	// limited, times out or fails with a server error
+	ChatFallbackModelIDs []string `json:"chat_fallback_model_ids,omitempty"`
+
+	// DisableCitations stops asking the model to cite the retrieved chunks
+	// inline as [n]
+	DisableCitations bool `json:"disable_citations,omitempty"`
+
+	// AnswerVerificationEnabled checks the answer's claims against the
+	// retrieved context after generation and handles unsupported ones with
+	// AnswerVerificationAction
+	AnswerVerificationEnabled bool                     `json:"answer_verification_enabled,omitempty"`
+	AnswerVerificationAction  AnswerVerificationAction `json:"answer_verification_action,omitempty"`
+
+	// FollowUpQuestionsEnabled suggests follow-up questions grounded in the
+	// retrieved chunks after the answer
+	FollowUpQuestionsEnabled bool `json:"follow_up_questions_enabled,omitempty"`
+
+	// Guardrails is the tenant's prompt-injection and PII filtering config
+	Guardrails *GuardrailsConfig `json:"-"`
+
+	// KBAutoRoute narrows the knowledge bases searched to the ones whose
+	// descriptions match the question, at most KBRouteMaxKBs of them
+	KBAutoRoute   bool `json:"kb_auto_route,omitempty"`
+	KBRouteMaxKBs int  `json:"kb_route_max_kbs,omitempty"`
 
 	// Rewrite parameters
 	EnableRewrite        bool   `json:"enable_rewrite"`
@@ -41,6 +72,17 @@ type PipelineRequest struct {
 	// Empty means fall back to ChatModelID.
 	QueryUnderstandModelID string `json:"query_understand_model_id,omitempty"`
 
+	// MultiQueryEnabled retrieves with MultiQueryCount LLM-generated
+	// variations of the question as well and fuses the results with RRF
+	MultiQueryEnabled    bool     `json:"multi_query_enabled,omitempty"`
+	MultiQueryCount      int      `json:"multi_query_count,omitempty"`
+	MultiQueryStrategies []string `json:"multi_query_strategies,omitempty"`
+
+	// ContextTokenBudget and ContextBudgetRatios size the context packed
+	// for the answering model, see ContextBudgetRatios
+	ContextTokenBudget  int                  `json:"context_token_budget,omitempty"`
+	ContextBudgetRatios *ContextBudgetRatios `json:"context_budget_ratios,omitempty"`
+
 	// FAQ strategy
 	FAQPriorityEnabled       bool    `json:"-"`
 	FAQDirectAnswerThreshold float64 `json:"-"`
@@ -51,6 +93,10 @@ type PipelineRequest struct {
 	// every RAG request that happens to retrieve CSV/Excel chunks.
 	DataAnalysisEnabled bool `json:"-"`
 
+	// SQLConnectionIDs are the databases the Text2SQL stage may answer
+	// analytical questions from. Empty disables the stage.
+	SQLConnectionIDs []string `json:"-"`
+
 	// Image / multimodal support
 	Images                  []string `json:"-"`
 	VLMModelID              string   `json:"-"`
@@ -86,6 +132,9 @@ const (
 	IntentDocOnly       QueryIntent = "doc_only"
 	IntentSummarize     QueryIntent = "summarize"
 	IntentClarification QueryIntent = "clarification"
+	// IntentDataQuery is set by the Text2SQL stage when it answered the
+	// question from a SQL connection; the retrieval stages are skipped.
+	IntentDataQuery QueryIntent = "data_query"
 )
 
 // NeedsKBRetrieval returns true when the intent requires knowledge base search.
@@ -121,6 +170,35 @@ type PipelineState struct {
 	ImageDescription     string            `json:"-"`
 	QuotedContext        string            `json:"-"` // Quoted message text, injected at LLM prompt stage
 	SystemPromptOverride string            `json:"-"`
+	// MemoryProfile is the user's remembered profile, appended to the
+	// system prompt by the memory plugin.
+	MemoryProfile string `json:"-"`
+	// MemorySessionSummary is the rolling summary of the session's earlier
+	// turns, injected ahead of the history by the memory plugin.
+	MemorySessionSummary string `json:"-"`
+	// MemoryEpisodes are the user's past episodes related to the question,
+	// most relevant first, appended to the user message.
+	MemoryEpisodes []string `json:"-"`
+	// RetrievalStatus is set by the search stage when HybridSearch served
+	// degraded results (see RetrievalDegradationPolicy).
+	RetrievalStatus RetrievalStatus `json:"-"`
+	// CitationSources are the retrieved chunks in the order they are
+	// numbered in the prompt; the answer cites the n-th one as [n].
+	CitationSources []*SearchResult `json:"-"`
+	// Citations are the sources cited by a non-streamed answer.
+	Citations Citations `json:"-"`
+	// AnswerVerification is the verification of a non-streamed answer.
+	AnswerVerification *AnswerVerification `json:"-"`
+	// FollowUpQuestions are the follow-ups suggested after a non-streamed
+	// answer.
+	FollowUpQuestions []string `json:"-"`
+	// AnswerMetadata records the chat model that generated the answer.
+	AnswerMetadata *MessageMetadata `json:"-"`
+	// GuardrailDetections are the rules the guardrails stage matched.
+	GuardrailDetections []GuardrailDetection `json:"-"`
+	// QueryVariants are the variations of the question retrieved alongside
+	// it in multi-query mode.
+	QueryVariants []QueryVariant `json:"-"`
 }
 
 // PipelineContext holds runtime context for the current pipeline execution.
@@ -128,6 +206,8 @@ type PipelineContext struct {
 	EventBus      EventBusInterface `json:"-"`
 	MessageID     string            `json:"-"`
 	UserMessageID string            `json:"-"`
+	// Trace records the stages of a debug-traced run; nil otherwise
+	Trace *PipelineTrace `json:"-"`
 }
 
 // ChatManage represents the full configuration, state and runtime context
@@ -189,47 +269,64 @@ func (c *ChatManage) Clone() *ChatManage {
 
 	return &ChatManage{
 		PipelineRequest: PipelineRequest{
-			Query:                    c.Query,
-			SessionID:                c.SessionID,
-			UserID:                   c.UserID,
-			EnableMemory:             c.EnableMemory,
-			MaxRounds:                c.MaxRounds,
-			KnowledgeBaseIDs:         knowledgeBaseIDs,
-			KnowledgeIDs:             knowledgeIDs,
-			SearchTargets:            searchTargets,
-			VectorThreshold:          c.VectorThreshold,
-			KeywordThreshold:         c.KeywordThreshold,
-			EmbeddingTopK:            c.EmbeddingTopK,
-			VectorDatabase:           c.VectorDatabase,
-			RerankModelID:            c.RerankModelID,
-			RerankTopK:               c.RerankTopK,
-			RerankThreshold:          c.RerankThreshold,
-			ChatModelID:              c.ChatModelID,
-			SummaryConfig:            c.SummaryConfig,
-			FallbackStrategy:         c.FallbackStrategy,
-			FallbackResponse:         c.FallbackResponse,
-			FallbackPrompt:           c.FallbackPrompt,
-			EnableRewrite:            c.EnableRewrite,
-			EnableQueryExpansion:     c.EnableQueryExpansion,
-			RewritePromptSystem:      c.RewritePromptSystem,
-			RewritePromptUser:        c.RewritePromptUser,
-			QueryUnderstandModelID:   c.QueryUnderstandModelID,
-			FAQPriorityEnabled:       c.FAQPriorityEnabled,
-			FAQDirectAnswerThreshold: c.FAQDirectAnswerThreshold,
-			FAQScoreBoost:            c.FAQScoreBoost,
-			DataAnalysisEnabled:      c.DataAnalysisEnabled,
-			Images:                   append([]string(nil), c.Images...),
-			VLMModelID:               c.VLMModelID,
-			ChatModelSupportsVision:  c.ChatModelSupportsVision,
-			Attachments:              append(MessageAttachments(nil), c.Attachments...),
-			TenantID:                 c.TenantID,
-			WebSearchEnabled:         c.WebSearchEnabled,
-			WebSearchProviderID:      c.WebSearchProviderID,
-			WebSearchMaxResults:      c.WebSearchMaxResults,
-			WebFetchEnabled:          c.WebFetchEnabled,
-			WebFetchTopN:             c.WebFetchTopN,
-			Language:                 c.Language,
-			IntentPromptOverrides:    maps.Clone(c.IntentPromptOverrides),
+			Query:                     c.Query,
+			SessionID:                 c.SessionID,
+			UserID:                    c.UserID,
+			EnableMemory:              c.EnableMemory,
+			MemoryPolicy:              c.MemoryPolicy,
+			Remember:                  c.Remember,
+			MaxRounds:                 c.MaxRounds,
+			KnowledgeBaseIDs:          knowledgeBaseIDs,
+			KnowledgeIDs:              knowledgeIDs,
+			SearchTargets:             searchTargets,
+			VectorThreshold:           c.VectorThreshold,
+			KeywordThreshold:          c.KeywordThreshold,
+			EmbeddingTopK:             c.EmbeddingTopK,
+			VectorDatabase:            c.VectorDatabase,
+			RerankModelID:             c.RerankModelID,
+			RerankTopK:                c.RerankTopK,
+			RerankThreshold:           c.RerankThreshold,
+			RerankFallbackModelIDs:    append([]string(nil), c.RerankFallbackModelIDs...),
+			ChatModelID:               c.ChatModelID,
+			ChatFallbackModelIDs:      append([]string(nil), c.ChatFallbackModelIDs...),
+			SummaryConfig:             c.SummaryConfig,
+			FallbackStrategy:          c.FallbackStrategy,
+			FallbackResponse:          c.FallbackResponse,
+			FallbackPrompt:            c.FallbackPrompt,
+			DisableCitations:          c.DisableCitations,
+			AnswerVerificationEnabled: c.AnswerVerificationEnabled,
+			AnswerVerificationAction:  c.AnswerVerificationAction,
+			FollowUpQuestionsEnabled:  c.FollowUpQuestionsEnabled,
+			Guardrails:                c.Guardrails,
+			KBAutoRoute:               c.KBAutoRoute,
+			KBRouteMaxKBs:             c.KBRouteMaxKBs,
+			EnableRewrite:             c.EnableRewrite,
+			EnableQueryExpansion:      c.EnableQueryExpansion,
+			MultiQueryEnabled:         c.MultiQueryEnabled,
+			MultiQueryCount:           c.MultiQueryCount,
+			MultiQueryStrategies:      append([]string(nil), c.MultiQueryStrategies...),
+			ContextTokenBudget:        c.ContextTokenBudget,
+			ContextBudgetRatios:       c.ContextBudgetRatios,
+			RewritePromptSystem:       c.RewritePromptSystem,
+			RewritePromptUser:         c.RewritePromptUser,
+			QueryUnderstandModelID:    c.QueryUnderstandModelID,
+			FAQPriorityEnabled:        c.FAQPriorityEnabled,
+			FAQDirectAnswerThreshold:  c.FAQDirectAnswerThreshold,
+			FAQScoreBoost:             c.FAQScoreBoost,
+			DataAnalysisEnabled:       c.DataAnalysisEnabled,
+			SQLConnectionIDs:          append([]string(nil), c.SQLConnectionIDs...),
+			Images:                    append([]string(nil), c.Images...),
+			VLMModelID:                c.VLMModelID,
+			ChatModelSupportsVision:   c.ChatModelSupportsVision,
+			Attachments:               append(MessageAttachments(nil), c.Attachments...),
+			TenantID:                  c.TenantID,
+			WebSearchEnabled:          c.WebSearchEnabled,
+			WebSearchProviderID:       c.WebSearchProviderID,
+			WebSearchMaxResults:       c.WebSearchMaxResults,
+			WebFetchEnabled:           c.WebFetchEnabled,
+			WebFetchTopN:              c.WebFetchTopN,
+			Language:                  c.Language,
+			IntentPromptOverrides:     maps.Clone(c.IntentPromptOverrides),
 		},
 		PipelineState: PipelineState{
 			RewriteQuery:         c.RewriteQuery,
@@ -237,6 +334,9 @@ func (c *ChatManage) Clone() *ChatManage {
 			ImageDescription:     c.ImageDescription,
 			QuotedContext:        c.QuotedContext,
 			SystemPromptOverride: c.SystemPromptOverride,
+			MemoryProfile:        c.MemoryProfile,
+			MemorySessionSummary: c.MemorySessionSummary,
+			MemoryEpisodes:       append([]string(nil), c.MemoryEpisodes...),
 			RenderedContexts:     c.RenderedContexts,
 			Entity:               entity,
 			EntityKBIDs:          entityKBIDs,
@@ -258,12 +358,20 @@ const (
 	WEB_FETCH              EventType = "web_fetch"
 	CHUNK_MERGE            EventType = "chunk_merge"
 	DATA_ANALYSIS          EventType = "data_analysis"
+	TEXT2SQL               EventType = "text2sql"
 	INTO_CHAT_MESSAGE      EventType = "into_chat_message"
 	CHAT_COMPLETION        EventType = "chat_completion"
 	CHAT_COMPLETION_STREAM EventType = "chat_completion_stream"
 	FILTER_TOP_K           EventType = "filter_top_k"
+	GUARDRAILS_INPUT       EventType = "guardrails_input"
+	GUARDRAILS_CONTEXT     EventType = "guardrails_context"
+	KB_ROUTE               EventType = "kb_route"
+	QUERY_VARIANTS         EventType = "query_variants"
+	CHUNK_CONTEXT_WINDOW   EventType = "chunk_context_window"
+	CONTEXT_PACK           EventType = "context_pack"
 	MEMORY_RETRIEVAL       EventType = "memory_retrieval"
 	MEMORY_STORAGE         EventType = "memory_storage"
+	MEMORY_QUERY_REWRITE   EventType = "memory_query_rewrite"
 )
 
 // PipelineBuilder dynamically assembles a pipeline as an ordered list of EventTypes.
@@ -37,6 +37,10 @@ const (
 	ChunkTypeTableColumn ChunkType = "table_column"
 	// ChunkTypeWikiPage 表示 Wiki 页面同步的 Chunk，用于将 wiki 页面接入现有检索管线
 	ChunkTypeWikiPage ChunkType = "wiki_page"
+	// ChunkTypeGlossary 表示自动构建的术语表条目 Chunk（按术语精确匹配，不参与向量索引）
+	ChunkTypeGlossary ChunkType = "glossary"
+	// ChunkTypeCommunityReport 表示知识图谱社区报告 Chunk（由图检索按主题召回，不参与向量索引）
+	ChunkTypeCommunityReport ChunkType = "community_report"
 )
 
 // ChunkStatus 定义了不同状态的 Chunk
@@ -156,6 +160,9 @@ type Chunk struct {
 	ContentHash string `json:"content_hash"             gorm:"type:varchar(64);index"`
 	// 图片信息，存储为 JSON
 	ImageInfo string `json:"image_info"               gorm:"type:text"`
+	// IndexGeneration 是 Chunk 所属的索引代次。普通写入为 0；索引迁移写入的 Chunk
+	// 带有迁移中的代次，在知识库切换到该代次（KnowledgeBase.IndexGeneration）之前对检索不可见
+	IndexGeneration int64 `json:"index_generation"         gorm:"default:0;index"`
 	// Chunk creation time
 	CreatedAt time.Time `json:"created_at"`
 	// Chunk last update time
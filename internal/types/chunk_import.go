@@ -0,0 +1,122 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ChunkImportRecord is one line of a bulk chunk import file (JSONL). Lines
+// sharing a DocumentID become the chunks of one knowledge entry and must be
+// contiguous in the file. Embedding is optional: chunks without one are
+// embedded with the knowledge base's embedding model.
+type ChunkImportRecord struct {
+	// DocumentID identifies the source document in the system the data is
+	// migrated from. Importing a DocumentID again replaces its chunks.
+	DocumentID string `json:"document_id"`
+	// Title, Source and Metadata describe the document; they are read from
+	// the first chunk of each document.
+	Title    string            `json:"title,omitempty"`
+	Source   string            `json:"source,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// ChunkIndex orders the chunks of a document; defaults to file order.
+	ChunkIndex *int      `json:"chunk_index,omitempty"`
+	Content    string    `json:"content"`
+	Embedding  []float32 `json:"embedding,omitempty"`
+}
+
+// ErrChunkImportDimension is returned by Validate when an embedding does
+// not match the knowledge base's embedding model.
+var ErrChunkImportDimension = errors.New("embedding dimension mismatch")
+
+// Validate checks a record against the knowledge base it is imported into.
+// dimension is the embedding model's dimension; 0 means the knowledge base
+// stores no vectors, so embeddings are not checked and later ignored.
+func (r *ChunkImportRecord) Validate(dimension int) error {
+	if strings.TrimSpace(r.DocumentID) == "" {
+		return errors.New("document_id is required")
+	}
+	if strings.TrimSpace(r.Content) == "" {
+		return errors.New("content is required")
+	}
+	if r.ChunkIndex != nil && *r.ChunkIndex < 0 {
+		return errors.New("chunk_index must not be negative")
+	}
+	if len(r.Embedding) == 0 || dimension == 0 {
+		return nil
+	}
+	if len(r.Embedding) != dimension {
+		return fmt.Errorf("%w: got %d, want %d", ErrChunkImportDimension, len(r.Embedding), dimension)
+	}
+	for _, v := range r.Embedding {
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return errors.New("embedding contains NaN or Inf")
+		}
+	}
+	return nil
+}
+
+// Chunk import limits. Batches bound the rows written per CreateChunks /
+// BatchSave call; the rate limit caps chunks written per second so a
+// migration does not starve interactive indexing on a shared vector store.
+const (
+	DefaultChunkImportBatchSize = 100
+	MaxChunkImportBatchSize     = 1000
+	// MaxChunkImportErrors caps the line errors kept in the progress.
+	MaxChunkImportErrors = 100
+)
+
+// ChunkImportPayload is the chunk:import task payload.
+type ChunkImportPayload struct {
+	TracingContext
+	TenantID uint64 `json:"tenant_id"`
+	TaskID   string `json:"task_id"`
+	KBID     string `json:"kb_id"`
+	// FilePath is the uploaded JSONL file in object storage, deleted when
+	// the import finishes.
+	FilePath  string `json:"file_path"`
+	FileSize  int64  `json:"file_size"`
+	BatchSize int    `json:"batch_size"`
+	// RateLimit caps chunks written per second; 0 means unlimited.
+	RateLimit int    `json:"rate_limit,omitempty"`
+	Channel   string `json:"channel,omitempty"`
+}
+
+// ChunkImportError describes a line that could not be imported. A document
+// with any invalid line is skipped as a whole.
+type ChunkImportError struct {
+	Line       int    `json:"line"`
+	DocumentID string `json:"document_id,omitempty"`
+	Message    string `json:"message"`
+}
+
+// ChunkImportProgress is the progress of a chunk import task.
+type ChunkImportProgress struct {
+	TaskID    string            `json:"task_id"`
+	KBID      string            `json:"kb_id"`
+	Status    KBCloneTaskStatus `json:"status"`
+	Progress  int               `json:"progress"` // 0-100, by bytes read
+	BytesRead int64             `json:"bytes_read"`
+	FileSize  int64             `json:"file_size"`
+	// Lines is the number of lines read up to BytesRead; a retried task
+	// resumes from there.
+	Lines int `json:"lines"`
+	// Documents and Chunks count what was written; FailedDocuments counts
+	// documents skipped because of invalid lines.
+	Documents       int                `json:"documents"`
+	Chunks          int                `json:"chunks"`
+	FailedDocuments int                `json:"failed_documents"`
+	Errors          []ChunkImportError `json:"errors,omitempty"`
+	Message         string             `json:"message"`
+	Error           string             `json:"error"`
+	CreatedAt       int64              `json:"created_at"`
+	UpdatedAt       int64              `json:"updated_at"`
+}
+
+// AddError records a line error, keeping at most MaxChunkImportErrors.
+func (p *ChunkImportProgress) AddError(e ChunkImportError) {
+	if len(p.Errors) < MaxChunkImportErrors {
+		p.Errors = append(p.Errors, e)
+	}
+}
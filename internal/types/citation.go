@@ -0,0 +1,45 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// Citation maps an inline [n] marker of a generated answer back to the
+// retrieved chunk numbered n in the prompt.
+type Citation struct {
+	// Index is the n of the [n] marker
+	Index           int    `json:"index"`
+	ChunkID         string `json:"chunk_id"`
+	KnowledgeID     string `json:"knowledge_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"`
+	KnowledgeTitle  string `json:"knowledge_title,omitempty"`
+}
+
+// Citations are the sources an answer cites, in order of first citation
+type Citations []Citation
+
+// Value implements the driver.Valuer interface for database serialization
+func (c Citations) Value() (driver.Value, error) {
+	if c == nil {
+		return json.Marshal([]Citation{})
+	}
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (c *Citations) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
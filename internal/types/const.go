@@ -33,6 +33,25 @@ const (
 	LangfuseTraceContextKey ContextKey = "LangfuseTrace"
 	// SystemAdminContextKey is the context key indicating whether the user is a system administrator
 	SystemAdminContextKey ContextKey = "SystemAdmin"
+	// RetrievalStatusContextKey carries a *RetrievalStatusRecorder so that
+	// HybridSearch can report degraded retrieval back to its caller without
+	// changing the search signature.
+	RetrievalStatusContextKey ContextKey = "RetrievalStatus"
+	// RetrievalTraceContextKey carries a *RetrievalTrace that pipeline
+	// stages fill with the candidates they drop, for debug responses.
+	RetrievalTraceContextKey ContextKey = "RetrievalTrace"
+	// SessionIDContextKey is the context key for the ID of the session a
+	// request answers in, used to attribute model token usage.
+	SessionIDContextKey ContextKey = "SessionID"
+	// IngestPriorityContextKey carries the IngestPriority lane for
+	// document:process tasks enqueued by background callers.
+	IngestPriorityContextKey ContextKey = "IngestPriority"
+	// APIKeyAuthContextKey is set to true when the request authenticated
+	// with the tenant's X-API-Key instead of a user login.
+	APIKeyAuthContextKey ContextKey = "APIKeyAuth"
+	// APIKeyContextKey carries the *APIKey of requests authenticated with a
+	// managed API key. See APIKeyFromContext.
+	APIKeyContextKey ContextKey = "APIKey"
 )
 
 // String returns the string representation of the context key
@@ -0,0 +1,78 @@
+package types
+
+// DefaultContextTokenBudget is the input token budget of the answering
+// model when the agent does not set one.
+const DefaultContextTokenBudget = 32000
+
+// ContextBudgetRatios splits the part of the context token budget left after
+// the system prompt and the question between the user's memory, the
+// retrieved chunks and the conversation history. A share one part does not
+// need goes to the others, in proportion to their ratios.
+type ContextBudgetRatios struct {
+	Memory  float64 `json:"memory"`
+	Chunks  float64 `json:"chunks"`
+	History float64 `json:"history"`
+}
+
+// DefaultContextBudgetRatios favours the retrieved chunks.
+var DefaultContextBudgetRatios = ContextBudgetRatios{Memory: 0.1, Chunks: 0.6, History: 0.3}
+
+// EffectiveContextTokenBudget returns budget, or the default when it is not
+// positive.
+func EffectiveContextTokenBudget(budget int) int {
+	if budget <= 0 {
+		return DefaultContextTokenBudget
+	}
+	return budget
+}
+
+// Effective returns the ratios normalized to sum to 1, treating negative
+// ratios as 0. Nil or all-zero ratios give DefaultContextBudgetRatios.
+func (r *ContextBudgetRatios) Effective() ContextBudgetRatios {
+	if r == nil {
+		return DefaultContextBudgetRatios
+	}
+	out := ContextBudgetRatios{Memory: max(r.Memory, 0), Chunks: max(r.Chunks, 0), History: max(r.History, 0)}
+	sum := out.Memory + out.Chunks + out.History
+	if sum == 0 {
+		return DefaultContextBudgetRatios
+	}
+	return ContextBudgetRatios{Memory: out.Memory / sum, Chunks: out.Chunks / sum, History: out.History / sum}
+}
+
+// Allocate splits available tokens between memory, chunks and history, which
+// need the given numbers of tokens. Each part first gets its ratio's share;
+// what a part does not use is handed to the parts still short, in proportion
+// to their ratios. A part with a zero ratio gets nothing.
+func (r *ContextBudgetRatios) Allocate(available, memory, chunks, history int) (int, int, int) {
+	ratios := r.Effective()
+	weights := [3]float64{ratios.Memory, ratios.Chunks, ratios.History}
+	need := [3]int{memory, chunks, history}
+	var got [3]int
+	remaining := max(available, 0)
+	for remaining > 0 {
+		var weight float64
+		for i := range need {
+			if got[i] < need[i] {
+				weight += weights[i]
+			}
+		}
+		if weight == 0 {
+			break
+		}
+		handed := 0
+		for i := range need {
+			if got[i] >= need[i] || weights[i] == 0 {
+				continue
+			}
+			share := min(int(float64(remaining)*weights[i]/weight), need[i]-got[i])
+			got[i] += share
+			handed += share
+		}
+		if handed == 0 {
+			break
+		}
+		remaining -= handed
+	}
+	return got[0], got[1], got[2]
+}
@@ -0,0 +1,51 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextBudgetRatiosEffective(t *testing.T) {
+	var nilRatios *ContextBudgetRatios
+	assert.Equal(t, DefaultContextBudgetRatios, nilRatios.Effective())
+	assert.Equal(t, DefaultContextBudgetRatios, (&ContextBudgetRatios{}).Effective())
+	assert.Equal(t, ContextBudgetRatios{Memory: 0, Chunks: 0.75, History: 0.25},
+		(&ContextBudgetRatios{Memory: -1, Chunks: 3, History: 1}).Effective())
+}
+
+func TestContextBudgetRatiosAllocate(t *testing.T) {
+	r := &ContextBudgetRatios{Memory: 0.1, Chunks: 0.6, History: 0.3}
+
+	t.Run("everything fits", func(t *testing.T) {
+		m, c, h := r.Allocate(1000, 50, 300, 100)
+		assert.Equal(t, [3]int{50, 300, 100}, [3]int{m, c, h})
+	})
+
+	t.Run("shares by ratio when all parts are short", func(t *testing.T) {
+		m, c, h := r.Allocate(1000, 5000, 5000, 5000)
+		assert.Equal(t, [3]int{100, 600, 300}, [3]int{m, c, h})
+	})
+
+	t.Run("unused shares go to the parts still short", func(t *testing.T) {
+		m, c, h := r.Allocate(1000, 0, 5000, 100)
+		assert.Equal(t, 0, m)
+		assert.Equal(t, 100, h)
+		assert.InDelta(t, 900, c, 2)
+	})
+
+	t.Run("zero ratio gets nothing", func(t *testing.T) {
+		m, c, h := (&ContextBudgetRatios{Chunks: 1}).Allocate(1000, 50, 300, 100)
+		assert.Equal(t, [3]int{0, 300, 0}, [3]int{m, c, h})
+	})
+
+	t.Run("no budget", func(t *testing.T) {
+		m, c, h := r.Allocate(-10, 50, 300, 100)
+		assert.Equal(t, [3]int{0, 0, 0}, [3]int{m, c, h})
+	})
+}
+
+func TestEffectiveContextTokenBudget(t *testing.T) {
+	assert.Equal(t, DefaultContextTokenBudget, EffectiveContextTokenBudget(0))
+	assert.Equal(t, 8000, EffectiveContextTokenBudget(8000))
+}
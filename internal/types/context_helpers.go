@@ -48,6 +48,12 @@ func RequestIDFromContext(ctx context.Context) (string, bool) {
 	return v, ok && v != ""
 }
 
+// SessionIDFromContext extracts the session ID string from ctx.
+func SessionIDFromContext(ctx context.Context) (string, bool) {
+	v, ok := ctx.Value(SessionIDContextKey).(string)
+	return v, ok && v != ""
+}
+
 // UserIDFromContext extracts the user ID string from ctx.
 func UserIDFromContext(ctx context.Context) (string, bool) {
 	v, ok := ctx.Value(UserIDContextKey).(string)
@@ -117,6 +117,8 @@ type CustomAgentConfig struct {
 	ModelID string `yaml:"model_id" json:"model_id"`
 	// ReRank model ID for retrieval
 	RerankModelID string `yaml:"rerank_model_id" json:"rerank_model_id"`
+	// ReRank models tried in order when the rerank model fails
+	RerankFallbackModelIDs []string `yaml:"rerank_fallback_model_ids" json:"rerank_fallback_model_ids,omitempty"`
 	// Temperature for LLM (0-1)
 	Temperature float64 `yaml:"temperature" json:"temperature"`
 	// Maximum completion tokens (only for normal mode)
@@ -129,6 +131,8 @@ type CustomAgentConfig struct {
 	MaxIterations int `yaml:"max_iterations" json:"max_iterations"`
 	// Timeout for a single LLM call in seconds (0 = use global default)
 	LLMCallTimeout int `yaml:"llm_call_timeout" json:"llm_call_timeout,omitempty"`
+	// Timeout for the whole ReAct loop in seconds (0 = no limit)
+	RunTimeout int `yaml:"run_timeout" json:"run_timeout,omitempty"`
 	// Allowed tools (only for agent type)
 	AllowedTools []string `yaml:"allowed_tools" json:"allowed_tools"`
 	// MCP service selection mode: "all" = all enabled MCP services, "selected" = specific services, "none" = no MCP
@@ -150,6 +154,12 @@ type CustomAgentConfig struct {
 	// When true, knowledge base retrieval only happens if user explicitly mentions KB/files with @
 	// When false, knowledge base retrieval happens according to KBSelectionMode
 	RetrieveKBOnlyWhenMentioned bool `yaml:"retrieve_kb_only_when_mentioned" json:"retrieve_kb_only_when_mentioned"`
+	// Whether to route each question to the knowledge bases whose descriptions
+	// match it, instead of searching every resolved knowledge base (normal mode).
+	// Ignored when the user mentions knowledge bases or files with @
+	KBAutoRoute bool `yaml:"kb_auto_route" json:"kb_auto_route,omitempty"`
+	// Maximum number of knowledge bases a question is routed to (default: 3)
+	KBRouteMaxKBs int `yaml:"kb_route_max_kbs" json:"kb_route_max_kbs,omitempty"`
 
 	// Whether to retain retrieval history across turns
 	RetainRetrievalHistory bool `yaml:"retain_retrieval_history" json:"retain_retrieval_history"`
@@ -180,6 +190,13 @@ type CustomAgentConfig struct {
 	// quick-answer / RAG-style agents do not want the added latency.
 	DataAnalysisEnabled bool `yaml:"data_analysis_enabled" json:"data_analysis_enabled"`
 
+	// ===== Text2SQL Settings =====
+	// Whether analytical questions may be answered with generated read-only
+	// SQL over the tenant's SQL connections (knowledge QA pipeline only)
+	Text2SQLEnabled bool `yaml:"text2sql_enabled" json:"text2sql_enabled"`
+	// SQL connection IDs the questions are routed between
+	SQLConnectionIDs []string `yaml:"sql_connection_ids" json:"sql_connection_ids"`
+
 	// ===== FAQ Strategy Settings =====
 	// Whether FAQ priority strategy is enabled (FAQ answers prioritized over document chunks)
 	FAQPriorityEnabled bool `yaml:"faq_priority_enabled" json:"faq_priority_enabled"`
@@ -222,6 +239,13 @@ type CustomAgentConfig struct {
 	// ===== Advanced Settings (mainly for normal mode) =====
 	// Whether to enable query expansion
 	EnableQueryExpansion bool `yaml:"enable_query_expansion" json:"enable_query_expansion"`
+	// Whether to retrieve with several LLM-generated variations of the question
+	// and fuse the results with RRF before reranking (normal mode)
+	MultiQueryEnabled bool `yaml:"multi_query_enabled" json:"multi_query_enabled,omitempty"`
+	// Number of query variations to generate (default: 3, max: 5)
+	MultiQueryCount int `yaml:"multi_query_count" json:"multi_query_count,omitempty"`
+	// Kinds of variations to generate: "paraphrase", "sub_question", "hyde" (default: all)
+	MultiQueryStrategies []string `yaml:"multi_query_strategies" json:"multi_query_strategies,omitempty"`
 	// Whether to enable query rewrite for multi-turn conversations
 	EnableRewrite bool `yaml:"enable_rewrite" json:"enable_rewrite"`
 	// Rewrite prompt system message
@@ -237,6 +261,20 @@ type CustomAgentConfig struct {
 	FallbackResponse string `yaml:"fallback_response" json:"fallback_response"`
 	// Fallback prompt (when FallbackStrategy is "model")
 	FallbackPrompt string `yaml:"fallback_prompt" json:"fallback_prompt"`
+	// Whether to stop asking the model to cite retrieved chunks inline as [n] (normal mode)
+	DisableCitations bool `yaml:"disable_citations" json:"disable_citations,omitempty"`
+	// Whether to check the answer's claims against the retrieved context after
+	// generation (normal mode)
+	AnswerVerificationEnabled bool `yaml:"answer_verification_enabled" json:"answer_verification_enabled,omitempty"`
+	// What to do about unsupported claims: "disclaimer" (default) or "regenerate"
+	AnswerVerificationAction AnswerVerificationAction `yaml:"answer_verification_action" json:"answer_verification_action,omitempty"`
+	// Whether to suggest follow-up questions after the answer (normal mode)
+	FollowUpQuestionsEnabled bool `yaml:"follow_up_questions_enabled" json:"follow_up_questions_enabled,omitempty"`
+	// Input token budget of the answering model (normal mode); memory, retrieved
+	// chunks and history are trimmed to fit, least relevant first (default: 32000)
+	ContextTokenBudget int `yaml:"context_token_budget" json:"context_token_budget,omitempty"`
+	// Shares of the budget for memory, retrieved chunks and history (default: 0.1/0.6/0.3)
+	ContextBudgetRatios *ContextBudgetRatios `yaml:"context_budget_ratios" json:"context_budget_ratios,omitempty"`
 	// IntentPrompts holds per-intent system prompt overrides for non-retrieval
 	// intents (greeting, chitchat, etc.). Empty values fall back to templates
 	// under config/prompt_templates/intent_prompts.yaml.
@@ -26,6 +26,8 @@ const (
 	ConnectorTypeSlack       = "slack"
 	ConnectorTypeIMAP        = "imap"
 	ConnectorTypeRSS         = "rss"
+	ConnectorTypeGit         = "git"
+	ConnectorTypeBucket      = "bucket"
 
 	// Sync modes
 	SyncModeIncremental = "incremental"
@@ -226,7 +228,11 @@ func (d DataSourceConfig) HasCredentials() bool {
 
 // HasConfiguredCredentials reports whether user-facing secret credentials are
 // stored. RSS feed URLs are non-secret configuration (settings); only
-// auth_headers count as credentials for that connector.
+// auth_headers count as credentials for that connector. Likewise the Git
+// repository URL and branch are settings; only the token and the webhook
+// secret are credentials. For object storage the bucket location is a
+// setting and only the access keys and the webhook secret are credentials;
+// for IMAP the server address is a setting.
 func (d DataSourceConfig) HasConfiguredCredentials(connectorType string) bool {
 	if len(d.Credentials) == 0 {
 		return false
@@ -239,6 +245,27 @@ func (d DataSourceConfig) HasConfiguredCredentials(connectorType string) bool {
 		}
 		s, ok := raw.(string)
 		return ok && strings.TrimSpace(s) != ""
+	case ConnectorTypeGit:
+		for _, key := range []string{"token", "webhook_secret"} {
+			if s, ok := d.Credentials[key].(string); ok && strings.TrimSpace(s) != "" {
+				return true
+			}
+		}
+		return false
+	case ConnectorTypeBucket:
+		for _, key := range []string{"access_key_id", "secret_access_key", "webhook_secret"} {
+			if s, ok := d.Credentials[key].(string); ok && strings.TrimSpace(s) != "" {
+				return true
+			}
+		}
+		return false
+	case ConnectorTypeIMAP:
+		for _, key := range []string{"username", "password"} {
+			if s, ok := d.Credentials[key].(string); ok && strings.TrimSpace(s) != "" {
+				return true
+			}
+		}
+		return false
 	default:
 		return len(d.Credentials) > 0
 	}
@@ -256,6 +283,26 @@ func (d *DataSourceConfig) StripNonSecretCredentials(connectorType string) {
 		if len(d.Credentials) == 0 {
 			d.Credentials = nil
 		}
+	case ConnectorTypeGit:
+		delete(d.Credentials, "repo_url")
+		delete(d.Credentials, "branch")
+		if len(d.Credentials) == 0 {
+			d.Credentials = nil
+		}
+	case ConnectorTypeBucket:
+		for _, key := range []string{"provider", "endpoint", "region", "bucket", "prefix", "force_path_style"} {
+			delete(d.Credentials, key)
+		}
+		if len(d.Credentials) == 0 {
+			d.Credentials = nil
+		}
+	case ConnectorTypeIMAP:
+		for _, key := range []string{"host", "port", "security", "since_days"} {
+			delete(d.Credentials, key)
+		}
+		if len(d.Credentials) == 0 {
+			d.Credentials = nil
+		}
 	}
 }
 
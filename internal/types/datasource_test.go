@@ -141,3 +141,68 @@ func TestDataSourceConfig_HasConfiguredCredentials_RSS(t *testing.T) {
 		assert.False(t, cfg.HasCredentials())
 	})
 }
+
+func TestDataSourceConfig_HasConfiguredCredentials_Git(t *testing.T) {
+	repoOnly := DataSourceConfig{
+		Credentials: map[string]interface{}{
+			"repo_url": "https://github.com/Tencent/WeKnora.git",
+			"branch":   "main",
+		},
+	}
+	assert.False(t, repoOnly.HasConfiguredCredentials(ConnectorTypeGit))
+
+	withToken := DataSourceConfig{
+		Credentials: map[string]interface{}{
+			"repo_url": "https://github.com/Tencent/WeKnora.git",
+			"token":    "ghp_x",
+		},
+	}
+	assert.True(t, withToken.HasConfiguredCredentials(ConnectorTypeGit))
+
+	withToken.StripNonSecretCredentials(ConnectorTypeGit)
+	assert.Equal(t, map[string]interface{}{"token": "ghp_x"}, withToken.Credentials)
+	repoOnly.StripNonSecretCredentials(ConnectorTypeGit)
+	assert.Nil(t, repoOnly.Credentials)
+}
+
+func TestDataSourceConfig_HasConfiguredCredentials_Bucket(t *testing.T) {
+	locationOnly := DataSourceConfig{
+		Credentials: map[string]interface{}{
+			"provider": "tos",
+			"bucket":   "docs",
+			"prefix":   "inbox/",
+		},
+	}
+	assert.False(t, locationOnly.HasConfiguredCredentials(ConnectorTypeBucket))
+
+	withKeys := DataSourceConfig{
+		Credentials: map[string]interface{}{
+			"bucket":            "docs",
+			"access_key_id":     "ak",
+			"secret_access_key": "sk",
+		},
+	}
+	assert.True(t, withKeys.HasConfiguredCredentials(ConnectorTypeBucket))
+
+	withKeys.StripNonSecretCredentials(ConnectorTypeBucket)
+	assert.Equal(t, map[string]interface{}{"access_key_id": "ak", "secret_access_key": "sk"}, withKeys.Credentials)
+	locationOnly.StripNonSecretCredentials(ConnectorTypeBucket)
+	assert.Nil(t, locationOnly.Credentials)
+}
+
+func TestDataSourceConfig_StripNonSecretCredentials_IMAP(t *testing.T) {
+	cfg := DataSourceConfig{
+		Credentials: map[string]interface{}{
+			"host":     "imap.example.com",
+			"port":     "993",
+			"username": "me@example.com",
+			"password": "pw",
+		},
+	}
+	assert.True(t, cfg.HasConfiguredCredentials(ConnectorTypeIMAP))
+	cfg.StripNonSecretCredentials(ConnectorTypeIMAP)
+	assert.Equal(t, map[string]interface{}{"username": "me@example.com", "password": "pw"}, cfg.Credentials)
+
+	hostOnly := DataSourceConfig{Credentials: map[string]interface{}{"host": "imap.example.com"}}
+	assert.False(t, hostOnly.HasConfiguredCredentials(ConnectorTypeIMAP))
+}
@@ -24,6 +24,29 @@ type ReadResult struct {
 	Error           string
 	IsAudio         bool   // true when the result contains raw audio data needing ASR transcription
 	AudioData       []byte // raw audio bytes for ASR processing
+	// LayoutBlocks is set when MarkdownContent was recognized by OCR: the
+	// text blocks in reading order, with their position on the page.
+	LayoutBlocks []LayoutBlock
+}
+
+// LayoutRegion is a rectangle on a page of the original document. BBox is
+// [x0, y0, x1, y1] in the pixel coordinates of the page image the OCR service
+// worked on, whose size is PageWidth x PageHeight when known.
+type LayoutRegion struct {
+	Page       int        `json:"page"` // 1-based
+	BBox       [4]float64 `json:"bbox"`
+	PageWidth  float64    `json:"page_width,omitempty"`
+	PageHeight float64    `json:"page_height,omitempty"`
+}
+
+// LayoutBlock is a text block recognized by OCR. Start and End are the rune
+// offsets of Text in ReadResult.MarkdownContent.
+type LayoutBlock struct {
+	LayoutRegion
+	Label string // block type reported by the OCR service, e.g. text, title, table
+	Text  string
+	Start int
+	End   int
 }
 
 // ImageRef represents an image reference extracted from the document.
@@ -87,6 +110,10 @@ type ParsedChunk struct {
 	// >= 0 means this is a child chunk referencing the parent at this index
 	// in the ParentChunks slice of ProcessChunksOptions.
 	ParentIndex int
+
+	// Metadata is stored as the chunk's document metadata, e.g. the time
+	// range of an audio transcript chunk. Nil for most chunks.
+	Metadata *DocumentChunkMetadata
 }
 
 // EmbeddingContent returns the text that should be sent to the embedding
@@ -0,0 +1,154 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Duplicate analysis statuses.
+const (
+	DuplicateStatusRunning   = "running"
+	DuplicateStatusCompleted = "completed"
+	DuplicateStatusFailed    = "failed"
+)
+
+// Duplicate analysis defaults and limits.
+const (
+	// DefaultDuplicateThreshold is the estimated Jaccard similarity above which
+	// two texts are near duplicates.
+	DefaultDuplicateThreshold = 0.9
+	MinDuplicateThreshold     = 0.5
+)
+
+// Duplicate group kinds.
+const (
+	// DuplicateKindExact groups copies whose normalized text is identical
+	DuplicateKindExact = "exact"
+	// DuplicateKindNear groups copies whose similarity is above the threshold
+	DuplicateKindNear = "near"
+)
+
+// Duplicate removal actions.
+const (
+	// DuplicateActionDisable disables the redundant chunks: they stay in the
+	// knowledge base but are no longer retrieved
+	DuplicateActionDisable = "disable"
+	// DuplicateActionDelete deletes the redundant chunks and their index rows;
+	// redundant documents are deleted entirely
+	DuplicateActionDelete = "delete"
+)
+
+// DuplicateReport is the latest duplicate analysis of a knowledge base. Each
+// knowledge base keeps one report, replaced by every new analysis.
+type DuplicateReport struct {
+	KnowledgeBaseID string  `json:"knowledge_base_id" gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64  `json:"tenant_id"         gorm:"index"`
+	Status          string  `json:"status"            gorm:"type:varchar(16)"`
+	Threshold       float64 `json:"threshold"`
+	// ChunkCount and DocumentCount are the chunks and documents compared
+	ChunkCount    int `json:"chunk_count"`
+	DocumentCount int `json:"document_count"`
+	// Truncated is set when the knowledge base had more chunks than one
+	// analysis compares or more groups than a report keeps; the rest were
+	// not reported
+	Truncated bool `json:"truncated"`
+	// DocumentGroups are documents duplicating an earlier document as a whole
+	DocumentGroups DuplicateGroups `json:"document_groups" gorm:"type:json"`
+	// ChunkGroups are chunks duplicating an earlier chunk, leaving out the
+	// chunks of documents already in DocumentGroups
+	ChunkGroups            DuplicateGroups `json:"chunk_groups"    gorm:"type:json"`
+	RedundantDocumentCount int             `json:"redundant_document_count"`
+	RedundantChunkCount    int             `json:"redundant_chunk_count"`
+	ErrorMessage           string          `json:"error_message"`
+	CreatedAt              time.Time       `json:"created_at"`
+	CompletedAt            *time.Time      `json:"completed_at"`
+	// RemovedAt is when the redundant copies of this report were last
+	// disabled or deleted
+	RemovedAt *time.Time `json:"removed_at"`
+}
+
+// TableName returns the table name of DuplicateReport
+func (DuplicateReport) TableName() string {
+	return "kb_duplicate_reports"
+}
+
+// DuplicateMember is one copy in a duplicate group: a chunk, or a whole
+// document when ChunkID is empty.
+type DuplicateMember struct {
+	KnowledgeID    string `json:"knowledge_id"`
+	KnowledgeTitle string `json:"knowledge_title"`
+	ChunkID        string `json:"chunk_id,omitempty"`
+	ChunkIndex     int    `json:"chunk_index,omitempty"`
+	// Preview is the start of the chunk content
+	Preview string `json:"preview,omitempty"`
+	// Similarity is the estimated similarity to the group's kept copy
+	Similarity float64 `json:"similarity"`
+	// Fingerprint is the hash of the normalized text at analysis time;
+	// removal skips chunks whose content changed since
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// DuplicateGroup is a kept copy and the redundant copies duplicating it.
+type DuplicateGroup struct {
+	Kind       string             `json:"kind"`
+	Keep       DuplicateMember    `json:"keep"`
+	Duplicates []*DuplicateMember `json:"duplicates"`
+}
+
+// DuplicateGroups is stored as a JSON column.
+type DuplicateGroups []*DuplicateGroup
+
+// Value implements the driver.Valuer interface
+func (g DuplicateGroups) Value() (driver.Value, error) {
+	return json.Marshal(g)
+}
+
+// Scan implements the sql.Scanner interface
+func (g *DuplicateGroups) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, g)
+	case string:
+		return json.Unmarshal([]byte(v), g)
+	}
+	return nil
+}
+
+// DuplicateAnalysisRequest starts a duplicate analysis.
+type DuplicateAnalysisRequest struct {
+	// Threshold is the near-duplicate similarity, between MinDuplicateThreshold
+	// and 1; 0 uses DefaultDuplicateThreshold
+	Threshold float64 `json:"threshold"`
+}
+
+// DuplicateRemoveRequest disables or deletes the redundant copies of the
+// latest report.
+type DuplicateRemoveRequest struct {
+	// Action is DuplicateActionDisable (default) or DuplicateActionDelete
+	Action string `json:"action"`
+	// SkipDocuments leaves the document groups alone
+	SkipDocuments bool `json:"skip_documents"`
+	// SkipChunks leaves the chunk groups alone
+	SkipChunks bool `json:"skip_chunks"`
+}
+
+// DuplicateRemoveResult counts what removing the duplicates of a report
+// changed.
+type DuplicateRemoveResult struct {
+	Action           string `json:"action"`
+	DocumentsDeleted int    `json:"documents_deleted"`
+	ChunksDisabled   int    `json:"chunks_disabled"`
+	ChunksDeleted    int    `json:"chunks_deleted"`
+	// Skipped counts copies left alone because they or their kept copy
+	// changed or disappeared since the analysis
+	Skipped int `json:"skipped"`
+}
+
+// DuplicateAnalysisPayload is the kb:duplicate_analysis task payload.
+type DuplicateAnalysisPayload struct {
+	TracingContext
+	TenantID        uint64  `json:"tenant_id"`
+	KnowledgeBaseID string  `json:"knowledge_base_id"`
+	Threshold       float64 `json:"threshold"`
+}
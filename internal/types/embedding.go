@@ -1,5 +1,11 @@
 package types
 
+import (
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
 // SourceType represents the type of content source
 type SourceType int
 
@@ -23,6 +29,7 @@ const (
 	MatchTypeWebSearch    // 网络搜索匹配类型
 	MatchTypeDirectLoad   // 直接加载匹配类型
 	MatchTypeDataAnalysis // 数据分析匹配类型
+	MatchTypeText2SQL     // SQL 查询结果匹配类型
 )
 
 // IndexInfo contains information about indexed content
@@ -38,4 +45,40 @@ type IndexInfo struct {
 	TagID           string     // Tag ID for categorization (used for FAQ priority filtering)
 	IsEnabled       bool       // Whether the chunk is enabled for retrieval
 	IsRecommended   bool       // Whether the chunk is recommended
+	// EmbeddingModelID is the model that produced the vector. Engines that
+	// keep one vector space per model (Milvus) route the row by it; it is
+	// stamped by the retrieve engine service at index time.
+	EmbeddingModelID string
+}
+
+// indexRowNamespace seeds IndexRowID. It must never change: stored row
+// keys are derived from it.
+var indexRowNamespace = uuid.MustParse("6f1b3c2e-8a4d-5e7f-9b0c-1d2e3f4a5b6c")
+
+// IndexRowID returns the deterministic primary key of the index row for
+// (chunkID, sourceID, sourceType). Engines that upsert by primary key use it
+// so a retried Save overwrites the earlier row instead of duplicating it.
+// The result is a UUID (v5), which every engine accepts as a row ID.
+func IndexRowID(chunkID, sourceID string, sourceType SourceType) string {
+	name := chunkID + "\x00" + sourceID + "\x00" + strconv.Itoa(int(sourceType))
+	return uuid.NewSHA1(indexRowNamespace, []byte(name)).String()
+}
+
+// RowID returns IndexRowID for this IndexInfo.
+func (i *IndexInfo) RowID() string {
+	return IndexRowID(i.ChunkID, i.SourceID, i.SourceType)
+}
+
+// ExportedIndex is an index row read back from a retrieve engine together
+// with its vector, as exported into knowledge base snapshots. Embedding is
+// empty for rows the engine stores without a vector (keyword-only engines).
+type ExportedIndex struct {
+	SourceID    string     `json:"source_id"`
+	SourceType  SourceType `json:"source_type"`
+	ChunkID     string     `json:"chunk_id"`
+	KnowledgeID string     `json:"knowledge_id"`
+	TagID       string     `json:"tag_id,omitempty"`
+	Content     string     `json:"content"`
+	IsEnabled   bool       `json:"is_enabled"`
+	Embedding   []float32  `json:"embedding,omitempty"`
 }
@@ -0,0 +1,25 @@
+package types
+
+import "testing"
+
+func TestIndexRowIDIsDeterministic(t *testing.T) {
+	a := IndexRowID("chunk-1", "chunk-1", ChunkSourceType)
+	if a != IndexRowID("chunk-1", "chunk-1", ChunkSourceType) {
+		t.Fatal("same inputs must yield the same row ID")
+	}
+	info := &IndexInfo{ChunkID: "chunk-1", SourceID: "chunk-1", SourceType: ChunkSourceType}
+	if info.RowID() != a {
+		t.Fatalf("RowID() = %q, want %q", info.RowID(), a)
+	}
+	for _, other := range []string{
+		IndexRowID("chunk-1", "chunk-1-q1", ChunkSourceType),
+		IndexRowID("chunk-1", "chunk-1", SummarySourceType),
+		IndexRowID("chunk-2", "chunk-1", ChunkSourceType),
+		// The separator keeps shifted boundaries from colliding.
+		IndexRowID("chunk-1chunk", "-1", ChunkSourceType),
+	} {
+		if other == a {
+			t.Fatalf("distinct inputs collided on %q", a)
+		}
+	}
+}
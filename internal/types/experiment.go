@@ -0,0 +1,467 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Shadow experiment limits.
+const (
+	// DefaultExperimentMaxSamples is the sample budget of an experiment
+	// created without one
+	DefaultExperimentMaxSamples = 500
+	// MaxExperimentSamples bounds the sample budget of one experiment
+	MaxExperimentSamples = 10000
+	// MaxExperimentKnowledgeBases bounds the knowledge bases an experiment
+	// is scoped to
+	MaxExperimentKnowledgeBases = 50
+)
+
+// ExperimentStatus is the state of a shadow experiment.
+type ExperimentStatus string
+
+const (
+	// ExperimentStatusRunning samples production answers
+	ExperimentStatusRunning ExperimentStatus = "running"
+	// ExperimentStatusPaused keeps the samples but takes no new ones
+	ExperimentStatusPaused ExperimentStatus = "paused"
+	// ExperimentStatusCompleted has used up its sample budget
+	ExperimentStatusCompleted ExperimentStatus = "completed"
+)
+
+// ExperimentWinner is the judge's verdict on a sample.
+type ExperimentWinner string
+
+const (
+	ExperimentWinnerProduction ExperimentWinner = "production"
+	ExperimentWinnerShadow     ExperimentWinner = "shadow"
+	ExperimentWinnerTie        ExperimentWinner = "tie"
+)
+
+// ExperimentConfig is the pipeline configuration a shadow experiment runs
+// next to production. Unset fields take the conversation defaults, so the
+// shadow differs from production in what is set here and in any agent,
+// tenant or session overrides production applied.
+type ExperimentConfig struct {
+	EmbeddingTopK    int      `json:"embedding_top_k"`
+	VectorThreshold  *float64 `json:"vector_threshold"`
+	KeywordThreshold *float64 `json:"keyword_threshold"`
+	// RerankModelID empty answers from the search results without reranking
+	RerankModelID   string   `json:"rerank_model_id"`
+	RerankTopK      int      `json:"rerank_top_k"`
+	RerankThreshold *float64 `json:"rerank_threshold"`
+	// ChatModelID answers in the shadow; defaults to the model production
+	// answered with, or the summary model of the searched knowledge base
+	ChatModelID string `json:"chat_model_id"`
+	// JudgeModelID compares the two answers; defaults to the shadow's chat model
+	JudgeModelID string `json:"judge_model_id"`
+}
+
+// Value implements the driver.Valuer interface
+func (c ExperimentConfig) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+// Scan implements the sql.Scanner interface
+func (c *ExperimentConfig) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	}
+	return nil
+}
+
+// Validate checks that the configuration changes something about the pipeline.
+func (c *ExperimentConfig) Validate() error {
+	if c.EmbeddingTopK < 0 || c.RerankTopK < 0 {
+		return errors.New("embedding_top_k and rerank_top_k must not be negative")
+	}
+	c.RerankModelID = strings.TrimSpace(c.RerankModelID)
+	c.ChatModelID = strings.TrimSpace(c.ChatModelID)
+	c.JudgeModelID = strings.TrimSpace(c.JudgeModelID)
+	if c.EmbeddingTopK == 0 && c.RerankTopK == 0 && c.VectorThreshold == nil && c.KeywordThreshold == nil &&
+		c.RerankThreshold == nil && c.RerankModelID == "" && c.ChatModelID == "" {
+		return errors.New("config must change at least one pipeline setting")
+	}
+	return nil
+}
+
+// Experiment runs an alternative pipeline configuration in the shadow of
+// production: a sampled share of the answers is answered again with the
+// experiment's configuration, without the user seeing it, and a judge model
+// compares the two answers.
+type Experiment struct {
+	ID          string `json:"id"          gorm:"type:varchar(36);primaryKey"`
+	TenantID    uint64 `json:"tenant_id"   gorm:"index"`
+	Name        string `json:"name"        gorm:"type:varchar(255)"`
+	Description string `json:"description" gorm:"type:text"`
+	// KnowledgeBaseIDs limits the experiment to answers that searched one
+	// of these knowledge bases; empty samples every answer that searched any
+	KnowledgeBaseIDs StringArray `json:"knowledge_base_ids" gorm:"type:json"`
+	// SamplePercent is the share of matching answers, from 0 to 100, that
+	// are answered again in the shadow
+	SamplePercent float64 `json:"sample_percent"`
+	// MaxSamples is the sample budget; the experiment completes once
+	// Sampled reaches it
+	MaxSamples int              `json:"max_samples"`
+	Sampled    int              `json:"sampled"`
+	Status     ExperimentStatus `json:"status"      gorm:"type:varchar(32)"`
+	Config     ExperimentConfig `json:"config"      gorm:"type:json"`
+	CreatedBy  string           `json:"created_by"  gorm:"type:varchar(36)"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+	// Report is filled when experiments are read through the API
+	Report *ExperimentReport `json:"report,omitempty" gorm:"-"`
+}
+
+// TableName returns the table name of Experiment
+func (Experiment) TableName() string {
+	return "experiments"
+}
+
+// Matches reports whether an answer that searched knowledgeBaseIDs is in
+// the experiment's scope.
+func (e *Experiment) Matches(knowledgeBaseIDs []string) bool {
+	if len(knowledgeBaseIDs) == 0 {
+		return false
+	}
+	if len(e.KnowledgeBaseIDs) == 0 {
+		return true
+	}
+	for _, id := range knowledgeBaseIDs {
+		for _, scoped := range e.KnowledgeBaseIDs {
+			if id == scoped {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExperimentRequest creates a shadow experiment.
+type ExperimentRequest struct {
+	Name             string           `json:"name"`
+	Description      string           `json:"description"`
+	KnowledgeBaseIDs []string         `json:"knowledge_base_ids"`
+	SamplePercent    float64          `json:"sample_percent"`
+	MaxSamples       int              `json:"max_samples"`
+	Config           ExperimentConfig `json:"config"`
+}
+
+// Validate checks the request, dedupes its knowledge bases and fills the
+// sample budget.
+func (r *ExperimentRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if err := validateExperimentSampling(r.SamplePercent, &r.MaxSamples); err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(r.KnowledgeBaseIDs))
+	ids := make([]string, 0, len(r.KnowledgeBaseIDs))
+	for _, id := range r.KnowledgeBaseIDs {
+		id = strings.TrimSpace(id)
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) > MaxExperimentKnowledgeBases {
+		return fmt.Errorf("at most %d knowledge bases are allowed", MaxExperimentKnowledgeBases)
+	}
+	r.KnowledgeBaseIDs = ids
+	return r.Config.Validate()
+}
+
+func validateExperimentSampling(samplePercent float64, maxSamples *int) error {
+	if samplePercent <= 0 || samplePercent > 100 {
+		return errors.New("sample_percent must be greater than 0 and at most 100")
+	}
+	if *maxSamples == 0 {
+		*maxSamples = DefaultExperimentMaxSamples
+	}
+	if *maxSamples < 0 || *maxSamples > MaxExperimentSamples {
+		return fmt.Errorf("max_samples must be between 1 and %d", MaxExperimentSamples)
+	}
+	return nil
+}
+
+// ExperimentUpdateRequest changes an experiment. The pipeline configuration
+// cannot change: samples taken with different configurations would not be
+// comparable, so a new configuration is a new experiment.
+type ExperimentUpdateRequest struct {
+	Name          *string  `json:"name"`
+	Description   *string  `json:"description"`
+	SamplePercent *float64 `json:"sample_percent"`
+	MaxSamples    *int     `json:"max_samples"`
+	// Status pauses or resumes the experiment: "running" or "paused"
+	Status *ExperimentStatus `json:"status"`
+}
+
+// ApplyTo validates the update and applies it to e. A running experiment
+// whose budget is used up is completed; raising the budget of a completed
+// experiment and setting it running resumes it.
+func (r *ExperimentUpdateRequest) ApplyTo(e *Experiment) error {
+	if r.Name != nil {
+		name := strings.TrimSpace(*r.Name)
+		if name == "" {
+			return errors.New("name is required")
+		}
+		e.Name = name
+	}
+	if r.Description != nil {
+		e.Description = *r.Description
+	}
+	samplePercent, maxSamples := e.SamplePercent, e.MaxSamples
+	if r.SamplePercent != nil {
+		samplePercent = *r.SamplePercent
+	}
+	if r.MaxSamples != nil {
+		maxSamples = *r.MaxSamples
+	}
+	if err := validateExperimentSampling(samplePercent, &maxSamples); err != nil {
+		return err
+	}
+	e.SamplePercent, e.MaxSamples = samplePercent, maxSamples
+	if r.Status != nil {
+		switch *r.Status {
+		case ExperimentStatusRunning, ExperimentStatusPaused:
+			e.Status = *r.Status
+		default:
+			return fmt.Errorf("status must be %q or %q", ExperimentStatusRunning, ExperimentStatusPaused)
+		}
+	}
+	if e.Status == ExperimentStatusRunning && e.Sampled >= e.MaxSamples {
+		e.Status = ExperimentStatusCompleted
+	}
+	return nil
+}
+
+// ExperimentObservation is a completed production answer offered to the
+// running experiments of its tenant.
+type ExperimentObservation struct {
+	SessionID string
+	MessageID string
+	Question  string
+	// ChatModelID is the model production answered with, when the request named one
+	ChatModelID      string
+	KnowledgeBaseIDs []string
+	KnowledgeIDs     []string
+	// Sources are the chunks production retrieved
+	Sources References
+}
+
+// SearchedKnowledgeBases returns the knowledge bases the answer searched:
+// those the request named and those production retrieved from, which
+// covers knowledge bases an agent selected on its own.
+func (o *ExperimentObservation) SearchedKnowledgeBases() []string {
+	seen := make(map[string]bool)
+	var ids []string
+	add := func(id string) {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range o.KnowledgeBaseIDs {
+		add(id)
+	}
+	for _, source := range o.Sources {
+		if source != nil {
+			add(source.KnowledgeBaseID)
+		}
+	}
+	return ids
+}
+
+// ExperimentSource is a chunk an answer was built from.
+type ExperimentSource struct {
+	ChunkID     string  `json:"chunk_id"`
+	KnowledgeID string  `json:"knowledge_id"`
+	Title       string  `json:"title"`
+	Score       float64 `json:"score"`
+}
+
+// ExperimentSources is the chunks an answer was built from
+type ExperimentSources []ExperimentSource
+
+// NewExperimentSources snapshots the chunks an answer was built from.
+func NewExperimentSources(results []*SearchResult) ExperimentSources {
+	sources := make(ExperimentSources, 0, len(results))
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		title := r.KnowledgeTitle
+		if title == "" {
+			title = r.KnowledgeFilename
+		}
+		sources = append(sources, ExperimentSource{
+			ChunkID:     r.ID,
+			KnowledgeID: r.KnowledgeID,
+			Title:       title,
+			Score:       r.Score,
+		})
+	}
+	return sources
+}
+
+// Value implements the driver.Valuer interface
+func (s ExperimentSources) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(s)
+	return string(b), err
+}
+
+// Scan implements the sql.Scanner interface
+func (s *ExperimentSources) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	}
+	return nil
+}
+
+// Overlap returns the Jaccard similarity of the chunks of two answers: 1
+// when both were built from the same chunks, 0 when they share none.
+func (s ExperimentSources) Overlap(other ExperimentSources) float64 {
+	a := make(map[string]bool, len(s))
+	for _, source := range s {
+		a[source.ChunkID] = true
+	}
+	union := len(a)
+	shared := 0
+	seen := make(map[string]bool, len(other))
+	for _, source := range other {
+		if seen[source.ChunkID] {
+			continue
+		}
+		seen[source.ChunkID] = true
+		if a[source.ChunkID] {
+			shared++
+		} else {
+			union++
+		}
+	}
+	if union == 0 {
+		return 1
+	}
+	return float64(shared) / float64(union)
+}
+
+// ExperimentSample is one production answer answered again in the shadow,
+// with the judge's verdict. Winner is empty when the shadow or the judge
+// failed; ErrMsg or JudgeReason says why.
+type ExperimentSample struct {
+	ID                string            `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	ExperimentID      string            `json:"experiment_id"     gorm:"type:varchar(36);index"`
+	TenantID          uint64            `json:"tenant_id"         gorm:"index"`
+	SessionID         string            `json:"session_id"        gorm:"type:varchar(36);index"`
+	MessageID         string            `json:"message_id"        gorm:"type:varchar(36);index"`
+	Question          string            `json:"question"          gorm:"type:text"`
+	ProductionAnswer  string            `json:"production_answer" gorm:"type:text"`
+	ShadowAnswer      string            `json:"shadow_answer"     gorm:"type:text"`
+	ProductionSources ExperimentSources `json:"production_sources" gorm:"type:json"`
+	ShadowSources     ExperimentSources `json:"shadow_sources"    gorm:"type:json"`
+	// SourceOverlap is the Jaccard similarity of the two answers' chunks
+	SourceOverlap   float64          `json:"source_overlap"`
+	ShadowLatencyMs int64            `json:"shadow_latency_ms"`
+	Winner          ExperimentWinner `json:"winner"       gorm:"type:varchar(16)"`
+	JudgeReason     string           `json:"judge_reason" gorm:"type:text"`
+	ErrMsg          string           `json:"err_msg"      gorm:"type:text"`
+	CreatedAt       time.Time        `json:"created_at"`
+}
+
+// TableName returns the table name of ExperimentSample
+func (ExperimentSample) TableName() string {
+	return "experiment_samples"
+}
+
+// ExperimentSampleCounts counts the samples of an experiment with one verdict
+type ExperimentSampleCounts struct {
+	ExperimentID string
+	Winner       ExperimentWinner
+	Count        int64
+	LatencySum   int64
+	OverlapSum   float64
+}
+
+// ExperimentReport sums up the samples of an experiment. The rates and
+// averages cover the judged samples.
+type ExperimentReport struct {
+	Samples           int64   `json:"samples"`
+	Judged            int64   `json:"judged"`
+	ShadowWins        int64   `json:"shadow_wins"`
+	ProductionWins    int64   `json:"production_wins"`
+	Ties              int64   `json:"ties"`
+	Failed            int64   `json:"failed"`
+	ShadowWinRate     float64 `json:"shadow_win_rate"`
+	ProductionWinRate float64 `json:"production_win_rate"`
+	TieRate           float64 `json:"tie_rate"`
+	// AvgShadowLatencyMs is the mean time the shadow pipeline took
+	AvgShadowLatencyMs int64 `json:"avg_shadow_latency_ms"`
+	// AvgSourceOverlap is how much the shadow's chunks overlap production's
+	AvgSourceOverlap float64 `json:"avg_source_overlap"`
+}
+
+// NewExperimentReport sums up the sample counts of one experiment.
+func NewExperimentReport(counts []*ExperimentSampleCounts) *ExperimentReport {
+	report := &ExperimentReport{}
+	var latency int64
+	var overlap float64
+	for _, c := range counts {
+		report.Samples += c.Count
+		switch c.Winner {
+		case ExperimentWinnerShadow:
+			report.ShadowWins += c.Count
+		case ExperimentWinnerProduction:
+			report.ProductionWins += c.Count
+		case ExperimentWinnerTie:
+			report.Ties += c.Count
+		default:
+			report.Failed += c.Count
+			continue
+		}
+		report.Judged += c.Count
+		latency += c.LatencySum
+		overlap += c.OverlapSum
+	}
+	if report.Judged > 0 {
+		judged := float64(report.Judged)
+		report.ShadowWinRate = float64(report.ShadowWins) / judged
+		report.ProductionWinRate = float64(report.ProductionWins) / judged
+		report.TieRate = float64(report.Ties) / judged
+		report.AvgShadowLatencyMs = latency / report.Judged
+		report.AvgSourceOverlap = overlap / judged
+	}
+	return report
+}
+
+// ExperimentShadowPayload is the payload of the shadow answer task
+type ExperimentShadowPayload struct {
+	TracingContext
+	TenantID     uint64 `json:"tenant_id"`
+	ExperimentID string `json:"experiment_id"`
+	SessionID    string `json:"session_id"`
+	MessageID    string `json:"message_id"`
+	// UserID is who asked; the shadow sees the documents whose ACL lets
+	// that user read them
+	UserID           string   `json:"user_id,omitempty"`
+	Language         string   `json:"language,omitempty"`
+	Question         string   `json:"question"`
+	ChatModelID      string   `json:"chat_model_id,omitempty"`
+	KnowledgeBaseIDs []string `json:"knowledge_base_ids,omitempty"`
+	KnowledgeIDs     []string `json:"knowledge_ids,omitempty"`
+}
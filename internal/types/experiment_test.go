@@ -0,0 +1,105 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExperimentRequest_Validate(t *testing.T) {
+	req := &ExperimentRequest{
+		Name:             " rerank v2 ",
+		KnowledgeBaseIDs: []string{"kb1", " kb1", "", "kb2"},
+		SamplePercent:    5,
+		Config:           ExperimentConfig{RerankModelID: " rerank-2 "},
+	}
+	require.NoError(t, req.Validate())
+	assert.Equal(t, "rerank v2", req.Name)
+	assert.Equal(t, []string{"kb1", "kb2"}, req.KnowledgeBaseIDs)
+	assert.Equal(t, DefaultExperimentMaxSamples, req.MaxSamples)
+	assert.Equal(t, "rerank-2", req.Config.RerankModelID)
+
+	valid := func() *ExperimentRequest {
+		return &ExperimentRequest{Name: "e", SamplePercent: 10, Config: ExperimentConfig{RerankTopK: 5}}
+	}
+	for name, mutate := range map[string]func(r *ExperimentRequest){
+		"no name":          func(r *ExperimentRequest) { r.Name = " " },
+		"zero percent":     func(r *ExperimentRequest) { r.SamplePercent = 0 },
+		"over 100 percent": func(r *ExperimentRequest) { r.SamplePercent = 100.5 },
+		"too many samples": func(r *ExperimentRequest) { r.MaxSamples = MaxExperimentSamples + 1 },
+		"no change":        func(r *ExperimentRequest) { r.Config = ExperimentConfig{JudgeModelID: "judge"} },
+		"negative top k":   func(r *ExperimentRequest) { r.Config.EmbeddingTopK = -1 },
+	} {
+		r := valid()
+		mutate(r)
+		assert.Error(t, r.Validate(), name)
+	}
+}
+
+func TestExperiment_Matches(t *testing.T) {
+	all := &Experiment{}
+	assert.True(t, all.Matches([]string{"kb1"}))
+	assert.False(t, all.Matches(nil), "answers without retrieval are never sampled")
+
+	scoped := &Experiment{KnowledgeBaseIDs: StringArray{"kb1", "kb2"}}
+	assert.True(t, scoped.Matches([]string{"kb9", "kb2"}))
+	assert.False(t, scoped.Matches([]string{"kb9"}))
+}
+
+func TestExperimentUpdateRequest_ApplyTo(t *testing.T) {
+	e := &Experiment{Name: "e", SamplePercent: 10, MaxSamples: 100, Sampled: 100, Status: ExperimentStatusCompleted}
+
+	running := ExperimentStatusRunning
+	require.NoError(t, (&ExperimentUpdateRequest{Status: &running}).ApplyTo(e))
+	assert.Equal(t, ExperimentStatusCompleted, e.Status, "a used up budget cannot resume")
+
+	budget := 200
+	require.NoError(t, (&ExperimentUpdateRequest{MaxSamples: &budget, Status: &running}).ApplyTo(e))
+	assert.Equal(t, ExperimentStatusRunning, e.Status)
+	assert.Equal(t, 200, e.MaxSamples)
+
+	completed := ExperimentStatusCompleted
+	assert.Error(t, (&ExperimentUpdateRequest{Status: &completed}).ApplyTo(e))
+	percent := 0.0
+	assert.Error(t, (&ExperimentUpdateRequest{SamplePercent: &percent}).ApplyTo(e))
+	empty := ""
+	assert.Error(t, (&ExperimentUpdateRequest{Name: &empty}).ApplyTo(e))
+}
+
+func TestExperimentObservation_SearchedKnowledgeBases(t *testing.T) {
+	o := &ExperimentObservation{
+		KnowledgeBaseIDs: []string{"kb1"},
+		Sources:          References{{KnowledgeBaseID: "kb2"}, nil, {KnowledgeBaseID: "kb1"}, {}},
+	}
+	assert.Equal(t, []string{"kb1", "kb2"}, o.SearchedKnowledgeBases())
+}
+
+func TestExperimentSources_Overlap(t *testing.T) {
+	a := NewExperimentSources([]*SearchResult{
+		{ID: "c1", KnowledgeFilename: "a.pdf"}, {ID: "c2"}, nil, {ID: "c3"},
+	})
+	require.Len(t, a, 3)
+	assert.Equal(t, "a.pdf", a[0].Title)
+
+	b := ExperimentSources{{ChunkID: "c2"}, {ChunkID: "c3"}, {ChunkID: "c3"}, {ChunkID: "c4"}}
+	assert.InDelta(t, 0.5, a.Overlap(b), 1e-9)
+	assert.InDelta(t, 1, ExperimentSources{}.Overlap(nil), 1e-9)
+	assert.InDelta(t, 0, a.Overlap(nil), 1e-9)
+}
+
+func TestNewExperimentReport(t *testing.T) {
+	report := NewExperimentReport([]*ExperimentSampleCounts{
+		{Winner: ExperimentWinnerShadow, Count: 6, LatencySum: 6000, OverlapSum: 3},
+		{Winner: ExperimentWinnerProduction, Count: 3, LatencySum: 3600, OverlapSum: 2.4},
+		{Winner: ExperimentWinnerTie, Count: 1, LatencySum: 400, OverlapSum: 1},
+		{Winner: "", Count: 2, LatencySum: 90000},
+	})
+	assert.Equal(t, &ExperimentReport{
+		Samples: 12, Judged: 10, ShadowWins: 6, ProductionWins: 3, Ties: 1, Failed: 2,
+		ShadowWinRate: 0.6, ProductionWinRate: 0.3, TieRate: 0.1,
+		AvgShadowLatencyMs: 1000, AvgSourceOverlap: 0.64,
+	}, report)
+
+	assert.Equal(t, &ExperimentReport{}, NewExperimentReport(nil))
+}
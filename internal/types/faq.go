@@ -36,6 +36,54 @@ type DocumentChunkMetadata struct {
 	// GeneratedQuestions 存储AI为该Chunk生成的相关问题
 	// 这些问题会被独立索引以提高召回率
 	GeneratedQuestions []GeneratedQuestion `json:"generated_questions,omitempty"`
+	// StartTime/EndTime 为音视频转写 Chunk 在录音中的起止时间（秒），用于定位播放
+	StartTime *float64 `json:"start_time,omitempty"`
+	EndTime   *float64 `json:"end_time,omitempty"`
+	// Speaker 为音视频转写 Chunk 的说话人标识
+	Speaker string `json:"speaker,omitempty"`
+	// Regions 为 OCR 识别的 Chunk 文本在原文页面上的区域，用于引用时高亮
+	Regions []LayoutRegion `json:"regions,omitempty"`
+	// HeadingPath 为 Chunk 所在的标题路径（由外到内），使用 markdown 分块策略时写入
+	HeadingPath []string `json:"heading_path,omitempty"`
+	// Table 为表格 Chunk 的列名与行范围，开启表格分块时写入
+	Table *TableChunkMetadata `json:"table,omitempty"`
+	// Code 为源代码 Chunk 的文件路径与符号信息，按符号切分源代码文件时写入
+	Code *CodeChunkMetadata `json:"code,omitempty"`
+	// ContextPreamble 为模型生成的上下文说明（1-2 句），描述该 Chunk 在整篇文档中的位置与主题，
+	// 开启 contextual_retrieval 时写入，并与 Chunk 内容一同向量化
+	ContextPreamble string `json:"context_preamble,omitempty"`
+}
+
+// TableChunkMetadata 描述表格 Chunk 对应的原文表格
+type TableChunkMetadata struct {
+	// Index 为表格在文档中的序号，从 0 开始
+	Index int `json:"index"`
+	// Columns 为表头列名
+	Columns []string `json:"columns,omitempty"`
+	// RowCount 为整个表格的数据行数
+	RowCount int `json:"row_count"`
+	// RowStart/RowEnd 为该 Chunk 包含的数据行范围（从 1 开始，含两端）；
+	// 超长表格按行拆分为多个 Chunk
+	RowStart int `json:"row_start,omitempty"`
+	RowEnd   int `json:"row_end,omitempty"`
+	// Summary 为模型生成的表格摘要，与表格 Chunk 一同索引
+	Summary string `json:"summary,omitempty"`
+}
+
+// CodeChunkMetadata 描述源代码 Chunk 对应的文件与符号
+type CodeChunkMetadata struct {
+	// FilePath 为文件在代码仓库中的路径
+	FilePath string `json:"file_path"`
+	// Language 为编程语言，如 go、python、typescript
+	Language string `json:"language,omitempty"`
+	// Symbol 为 Chunk 所属的函数、方法或类型的限定名，如 Server.Start；
+	// 符号之间的代码（import、常量等）为空
+	Symbol string `json:"symbol,omitempty"`
+	// SymbolKind 为符号类型，如 function、method、class、struct
+	SymbolKind string `json:"symbol_kind,omitempty"`
+	// StartLine/EndLine 为 Chunk 在文件中的行范围（从 1 开始，含两端）
+	StartLine int `json:"start_line"`
+	EndLine   int `json:"end_line"`
 }
 
 // GetQuestionStrings 返回问题内容字符串列表（兼容旧代码）
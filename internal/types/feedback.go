@@ -0,0 +1,309 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxFeedbackCommentLength is the maximum length of a feedback comment in runes
+	MaxFeedbackCommentLength = 2000
+	// FeedbackScoreBuckets is the number of equal-width buckets source scores
+	// in [0, 1] are counted in
+	FeedbackScoreBuckets = 10
+)
+
+// FeedbackRating is a thumbs up or down on an answer. It is empty for
+// feedback that only marks wrong sources.
+type FeedbackRating string
+
+const (
+	FeedbackRatingUp   FeedbackRating = "up"
+	FeedbackRatingDown FeedbackRating = "down"
+)
+
+// FeedbackExportFormat selects the shape of exported feedback lines
+type FeedbackExportFormat string
+
+const (
+	// FeedbackExportRaw exports one MessageFeedback with its sources per line
+	FeedbackExportRaw FeedbackExportFormat = "feedback"
+	// FeedbackExportEval exports the up-voted answers as evaluation dataset
+	// items, expecting the sources they cite
+	FeedbackExportEval FeedbackExportFormat = "eval"
+	// FeedbackExportRerank exports question, positive and negative passage
+	// triples for reranker training
+	FeedbackExportRerank FeedbackExportFormat = "rerank"
+)
+
+// MessageFeedback is a user's feedback on an assistant message. Each user
+// keeps one feedback per message; the question, the answer and the sources
+// are snapshotted so the feedback stays usable after the session is cleared
+// or the documents are re-chunked.
+type MessageFeedback struct {
+	ID        string         `json:"id"         gorm:"type:varchar(36);primaryKey"`
+	TenantID  uint64         `json:"tenant_id"  gorm:"index"`
+	SessionID string         `json:"session_id" gorm:"type:varchar(36)"`
+	MessageID string         `json:"message_id" gorm:"type:varchar(36);uniqueIndex:idx_message_feedback_user"`
+	UserID    string         `json:"user_id"    gorm:"type:varchar(36);uniqueIndex:idx_message_feedback_user"`
+	Rating    FeedbackRating `json:"rating"     gorm:"type:varchar(16)"`
+	Comment   string         `json:"comment"    gorm:"type:text"`
+	// WrongSources is the number of sources the user marked as wrong
+	WrongSources int `json:"wrong_sources"`
+	// Day in UTC the feedback was last given, formatted as TokenUsageDayLayout
+	Day      string `json:"day"      gorm:"type:varchar(10);index"`
+	Question string `json:"question" gorm:"type:text"`
+	Answer   string `json:"answer"   gorm:"type:text"`
+	// Sources are the retrieved chunks the answer was generated from
+	Sources   []*FeedbackSource `json:"sources,omitempty" gorm:"-"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// TableName returns the table name of MessageFeedback
+func (MessageFeedback) TableName() string {
+	return "message_feedbacks"
+}
+
+// FeedbackSource is a snapshot of one retrieved chunk of a message that
+// received feedback, with whether the answer cited it and whether the user
+// marked it as wrong.
+type FeedbackSource struct {
+	FeedbackID      string  `json:"-"                 gorm:"type:varchar(36);primaryKey"`
+	Position        int     `json:"position"          gorm:"primaryKey;autoIncrement:false"`
+	TenantID        uint64  `json:"-"                 gorm:"index"`
+	ChunkID         string  `json:"chunk_id"          gorm:"type:varchar(64);index"`
+	KnowledgeID     string  `json:"knowledge_id"      gorm:"type:varchar(36)"`
+	KnowledgeBaseID string  `json:"knowledge_base_id" gorm:"type:varchar(36);index"`
+	KnowledgeTitle  string  `json:"knowledge_title"`
+	Content         string  `json:"content"           gorm:"type:text"`
+	Score           float64 `json:"score"`
+	// ScoreBucket is the index of the FeedbackScoreBuckets bucket of Score
+	ScoreBucket int  `json:"-"`
+	Cited       bool `json:"cited"`
+	Wrong       bool `json:"wrong"`
+}
+
+// TableName returns the table name of FeedbackSource
+func (FeedbackSource) TableName() string {
+	return "message_feedback_sources"
+}
+
+// FeedbackScoreBucket returns the bucket of a source score, clamping scores
+// outside [0, 1] to the first and last bucket.
+func FeedbackScoreBucket(score float64) int {
+	bucket := int(score * FeedbackScoreBuckets)
+	if bucket < 0 {
+		return 0
+	}
+	if bucket >= FeedbackScoreBuckets {
+		return FeedbackScoreBuckets - 1
+	}
+	return bucket
+}
+
+// NewFeedbackSources snapshots the references of an answer, marking the
+// cited chunks and those in wrongChunkIDs.
+func NewFeedbackSources(refs References, citations Citations, wrongChunkIDs []string) []*FeedbackSource {
+	cited := make(map[string]bool, len(citations))
+	for _, c := range citations {
+		cited[c.ChunkID] = true
+	}
+	wrong := make(map[string]bool, len(wrongChunkIDs))
+	for _, id := range wrongChunkIDs {
+		wrong[id] = true
+	}
+	sources := make([]*FeedbackSource, 0, len(refs))
+	for _, ref := range refs {
+		if ref == nil {
+			continue
+		}
+		sources = append(sources, &FeedbackSource{
+			Position:        len(sources),
+			ChunkID:         ref.ID,
+			KnowledgeID:     ref.KnowledgeID,
+			KnowledgeBaseID: ref.KnowledgeBaseID,
+			KnowledgeTitle:  ref.KnowledgeTitle,
+			Content:         ref.Content,
+			Score:           ref.Score,
+			ScoreBucket:     FeedbackScoreBucket(ref.Score),
+			Cited:           cited[ref.ID],
+			Wrong:           wrong[ref.ID],
+		})
+	}
+	return sources
+}
+
+// MessageFeedbackRequest gives feedback on an assistant message: a rating,
+// the IDs of the retrieved chunks that were wrong sources, or both.
+type MessageFeedbackRequest struct {
+	Rating        FeedbackRating `json:"rating"`
+	Comment       string         `json:"comment"`
+	WrongChunkIDs []string       `json:"wrong_chunk_ids"`
+}
+
+// Validate checks the rating and the comment and deduplicates the wrong
+// chunk IDs.
+func (r *MessageFeedbackRequest) Validate() error {
+	switch r.Rating {
+	case FeedbackRatingUp, FeedbackRatingDown, "":
+	default:
+		return fmt.Errorf("rating must be %q or %q", FeedbackRatingUp, FeedbackRatingDown)
+	}
+	r.Comment = strings.TrimSpace(r.Comment)
+	if len([]rune(r.Comment)) > MaxFeedbackCommentLength {
+		return fmt.Errorf("comment must not exceed %d characters", MaxFeedbackCommentLength)
+	}
+	seen := make(map[string]bool, len(r.WrongChunkIDs))
+	ids := r.WrongChunkIDs[:0]
+	for _, id := range r.WrongChunkIDs {
+		id = strings.TrimSpace(id)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	r.WrongChunkIDs = ids
+	if r.Rating == "" && len(r.WrongChunkIDs) == 0 {
+		return errors.New("either rating or wrong_chunk_ids is required")
+	}
+	return nil
+}
+
+// FeedbackQuery selects the feedback of a tenant for stats and exports.
+// StartDay and EndDay are inclusive and formatted as TokenUsageDayLayout;
+// KnowledgeBaseID keeps the feedback on answers with a source from that
+// knowledge base.
+type FeedbackQuery struct {
+	TenantID        uint64
+	StartDay        string
+	EndDay          string
+	KnowledgeBaseID string
+	Rating          FeedbackRating
+}
+
+// FeedbackCounts counts feedback by kind. WrongSource counts the feedback
+// that marked at least one wrong source.
+type FeedbackCounts struct {
+	Total       int64 `json:"total"`
+	Up          int64 `json:"up"`
+	Down        int64 `json:"down"`
+	WrongSource int64 `json:"wrong_source"`
+}
+
+// Add adds the counts of o to c
+func (c *FeedbackCounts) Add(o FeedbackCounts) {
+	c.Total += o.Total
+	c.Up += o.Up
+	c.Down += o.Down
+	c.WrongSource += o.WrongSource
+}
+
+// FeedbackDayCounts is the feedback given on one day
+type FeedbackDayCounts struct {
+	Day string `json:"day"`
+	FeedbackCounts
+}
+
+// FeedbackSourceCounts counts the sources of rated answers. Sources counts
+// all retrieved sources, UpSources and DownSources those of up- and
+// down-voted answers, Wrong those marked as wrong.
+type FeedbackSourceCounts struct {
+	Sources     int64 `json:"sources"`
+	UpSources   int64 `json:"up_sources"`
+	DownSources int64 `json:"down_sources"`
+	Wrong       int64 `json:"wrong"`
+}
+
+// FeedbackKnowledgeBaseCounts counts the sources of one knowledge base
+type FeedbackKnowledgeBaseCounts struct {
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	FeedbackSourceCounts
+}
+
+// FeedbackScoreBucketCounts counts the sources whose score falls in
+// [MinScore, MaxScore). Comparing the share of wrong sources across buckets
+// shows where a retrieval or rerank threshold would cut them off.
+type FeedbackScoreBucketCounts struct {
+	MinScore float64 `json:"min_score"`
+	MaxScore float64 `json:"max_score"`
+	FeedbackSourceCounts
+}
+
+// FeedbackWrongSource is a chunk users marked as a wrong source
+type FeedbackWrongSource struct {
+	ChunkID         string `json:"chunk_id"`
+	KnowledgeID     string `json:"knowledge_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	KnowledgeTitle  string `json:"knowledge_title"`
+	Count           int64  `json:"count"`
+}
+
+// FeedbackStats aggregates the feedback of a tenant over a date range
+type FeedbackStats struct {
+	StartDate       string                         `json:"start_date"`
+	EndDate         string                         `json:"end_date"`
+	Total           FeedbackCounts                 `json:"total"`
+	Days            []*FeedbackDayCounts           `json:"days"`
+	KnowledgeBases  []*FeedbackKnowledgeBaseCounts `json:"knowledge_bases"`
+	ScoreBuckets    []*FeedbackScoreBucketCounts   `json:"score_buckets"`
+	TopWrongSources []*FeedbackWrongSource         `json:"top_wrong_sources"`
+}
+
+// FeedbackRerankSample is a reranker training sample: a question with the
+// passages users confirmed and those they marked as wrong.
+type FeedbackRerankSample struct {
+	Query     string   `json:"query"`
+	Positives []string `json:"positives"`
+	Negatives []string `json:"negatives"`
+}
+
+// EvalItem turns up-voted feedback into an evaluation dataset item that
+// expects the cited sources that were not marked wrong, or the uncited
+// ones when the answer cites none. It returns nil when the feedback is not
+// up-voted or has no such source.
+func (f *MessageFeedback) EvalItem() *EvalDatasetItemRequest {
+	if f.Rating != FeedbackRatingUp || strings.TrimSpace(f.Question) == "" {
+		return nil
+	}
+	anyCited := false
+	for _, s := range f.Sources {
+		anyCited = anyCited || s.Cited
+	}
+	item := &EvalDatasetItemRequest{Question: f.Question, ExpectedAnswer: f.Answer}
+	for _, s := range f.Sources {
+		if s.Wrong || (anyCited && !s.Cited) || len(item.ExpectedSources) == MaxEvalExpectedSources {
+			continue
+		}
+		item.ExpectedSources = append(item.ExpectedSources, EvalExpectedSource{ChunkID: s.ChunkID})
+	}
+	if len(item.ExpectedSources) == 0 {
+		return nil
+	}
+	return item
+}
+
+// RerankSample turns feedback into a reranker training sample. Wrong
+// sources are negatives; the cited sources of up-voted answers are
+// positives. It returns nil when there is neither.
+func (f *MessageFeedback) RerankSample() *FeedbackRerankSample {
+	if strings.TrimSpace(f.Question) == "" {
+		return nil
+	}
+	sample := &FeedbackRerankSample{Query: f.Question, Positives: []string{}, Negatives: []string{}}
+	for _, s := range f.Sources {
+		switch {
+		case s.Wrong:
+			sample.Negatives = append(sample.Negatives, s.Content)
+		case s.Cited && f.Rating == FeedbackRatingUp:
+			sample.Positives = append(sample.Positives, s.Content)
+		}
+	}
+	if len(sample.Positives) == 0 && len(sample.Negatives) == 0 {
+		return nil
+	}
+	return sample
+}
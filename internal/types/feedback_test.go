@@ -0,0 +1,93 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageFeedbackRequest_Validate(t *testing.T) {
+	req := &MessageFeedbackRequest{Rating: FeedbackRatingDown, Comment: "  wrong  ", WrongChunkIDs: []string{"c1", " c1", "", "c2"}}
+	require.NoError(t, req.Validate())
+	assert.Equal(t, "wrong", req.Comment)
+	assert.Equal(t, []string{"c1", "c2"}, req.WrongChunkIDs)
+
+	assert.NoError(t, (&MessageFeedbackRequest{WrongChunkIDs: []string{"c1"}}).Validate(), "wrong sources alone are feedback")
+	assert.Error(t, (&MessageFeedbackRequest{}).Validate())
+	assert.Error(t, (&MessageFeedbackRequest{Rating: "meh"}).Validate())
+	assert.Error(t, (&MessageFeedbackRequest{
+		Rating: FeedbackRatingUp, Comment: strings.Repeat("好", MaxFeedbackCommentLength+1),
+	}).Validate())
+}
+
+func TestFeedbackScoreBucket(t *testing.T) {
+	assert.Equal(t, 0, FeedbackScoreBucket(-0.2))
+	assert.Equal(t, 0, FeedbackScoreBucket(0.05))
+	assert.Equal(t, 3, FeedbackScoreBucket(0.35))
+	assert.Equal(t, 9, FeedbackScoreBucket(1))
+	assert.Equal(t, 9, FeedbackScoreBucket(7.5))
+}
+
+func TestNewFeedbackSources(t *testing.T) {
+	sources := NewFeedbackSources(
+		References{
+			{ID: "c1", KnowledgeID: "k1", KnowledgeBaseID: "kb1", Content: "one", Score: 0.82},
+			nil,
+			{ID: "c2", KnowledgeID: "k2", Content: "two", Score: 0.4},
+		},
+		Citations{{Index: 1, ChunkID: "c1"}},
+		[]string{"c2", "c9"},
+	)
+	require.Len(t, sources, 2)
+	assert.Equal(t, &FeedbackSource{
+		Position: 0, ChunkID: "c1", KnowledgeID: "k1", KnowledgeBaseID: "kb1", Content: "one",
+		Score: 0.82, ScoreBucket: 8, Cited: true,
+	}, sources[0])
+	assert.Equal(t, 1, sources[1].Position)
+	assert.True(t, sources[1].Wrong)
+	assert.False(t, sources[1].Cited)
+}
+
+func TestMessageFeedback_EvalItem(t *testing.T) {
+	f := &MessageFeedback{
+		Rating: FeedbackRatingUp, Question: "q", Answer: "a",
+		Sources: []*FeedbackSource{
+			{ChunkID: "c1", Cited: true},
+			{ChunkID: "c2"},
+			{ChunkID: "c3", Cited: true, Wrong: true},
+		},
+	}
+	assert.Equal(t, &EvalDatasetItemRequest{
+		Question: "q", ExpectedAnswer: "a", ExpectedSources: []EvalExpectedSource{{ChunkID: "c1"}},
+	}, f.EvalItem())
+
+	uncited := &MessageFeedback{Rating: FeedbackRatingUp, Question: "q",
+		Sources: []*FeedbackSource{{ChunkID: "c1"}, {ChunkID: "c2", Wrong: true}}}
+	assert.Equal(t, []EvalExpectedSource{{ChunkID: "c1"}}, uncited.EvalItem().ExpectedSources,
+		"answers without citations expect their sources that were not wrong")
+
+	f.Rating = FeedbackRatingDown
+	assert.Nil(t, f.EvalItem())
+	assert.Nil(t, (&MessageFeedback{Rating: FeedbackRatingUp, Question: "q"}).EvalItem())
+}
+
+func TestMessageFeedback_RerankSample(t *testing.T) {
+	f := &MessageFeedback{
+		Rating: FeedbackRatingUp, Question: "q",
+		Sources: []*FeedbackSource{
+			{Content: "good", Cited: true},
+			{Content: "unused"},
+			{Content: "bad", Wrong: true},
+		},
+	}
+	assert.Equal(t, &FeedbackRerankSample{Query: "q", Positives: []string{"good"}, Negatives: []string{"bad"}}, f.RerankSample())
+
+	f.Rating = FeedbackRatingDown
+	assert.Equal(t, &FeedbackRerankSample{Query: "q", Positives: []string{}, Negatives: []string{"bad"}}, f.RerankSample(),
+		"cited sources of down-voted answers are not positives")
+
+	f.Sources = f.Sources[:2]
+	assert.Nil(t, f.RerankSample())
+}
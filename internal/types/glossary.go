@@ -0,0 +1,45 @@
+package types
+
+import "encoding/json"
+
+// GlossaryEntry is one term of a knowledge base glossary. Entries are stored
+// as ChunkTypeGlossary chunks under the KB's KnowledgeTypeGlossary knowledge,
+// with the entry serialized in the chunk metadata.
+type GlossaryEntry struct {
+	Term       string `json:"term"`
+	Definition string `json:"definition"`
+	// Expansion is the long form found next to an acronym in the source
+	// text, e.g. "Service Level Agreement" for "SLA".
+	Expansion string `json:"expansion,omitempty"`
+	// Occurrences counts how often the term appeared in the mined chunks.
+	Occurrences int `json:"occurrences"`
+	// KnowledgeBaseID is filled on lookup; it is not persisted.
+	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"`
+}
+
+// GlossaryMetadata parses the glossary entry stored on a glossary chunk.
+func (c *Chunk) GlossaryMetadata() (*GlossaryEntry, error) {
+	if c == nil || len(c.Metadata) == 0 {
+		return nil, nil
+	}
+	var entry GlossaryEntry
+	if err := json.Unmarshal(c.Metadata, &entry); err != nil {
+		return nil, err
+	}
+	entry.KnowledgeBaseID = c.KnowledgeBaseID
+	return &entry, nil
+}
+
+// SetGlossaryMetadata stores entry on the chunk and mirrors it into Content
+// so the chunk reads naturally wherever chunks are listed.
+func (c *Chunk) SetGlossaryMetadata(entry *GlossaryEntry) error {
+	stored := *entry
+	stored.KnowledgeBaseID = ""
+	bytes, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	c.Metadata = JSON(bytes)
+	c.Content = entry.Term + ": " + entry.Definition
+	return nil
+}
@@ -0,0 +1,78 @@
+package types
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// GraphCommunity is a densely connected group of entities in a knowledge
+// base's graph, with the report the KB's summary model wrote about it.
+// Communities are stored as ChunkTypeCommunityReport chunks under the KB's
+// KnowledgeTypeGraphCommunity knowledge, with the community serialized in the
+// chunk metadata.
+type GraphCommunity struct {
+	Title    string   `json:"title"`
+	Summary  string   `json:"summary"`
+	Findings []string `json:"findings,omitempty"`
+	// Rating is the model's 0-10 estimate of how important the community is
+	// to the knowledge base as a whole.
+	Rating   float64  `json:"rating"`
+	Entities []string `json:"entities"`
+	// ChunkIDs are the source chunks the community's entities were
+	// extracted from.
+	ChunkIDs []string `json:"chunk_ids,omitempty"`
+	// KnowledgeBaseID is filled on lookup; it is not persisted.
+	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"`
+}
+
+// GraphCommunityMetadata parses the community stored on a community report
+// chunk.
+func (c *Chunk) GraphCommunityMetadata() (*GraphCommunity, error) {
+	if c == nil || len(c.Metadata) == 0 {
+		return nil, nil
+	}
+	var community GraphCommunity
+	if err := json.Unmarshal(c.Metadata, &community); err != nil {
+		return nil, err
+	}
+	community.KnowledgeBaseID = c.KnowledgeBaseID
+	return &community, nil
+}
+
+// SetGraphCommunityMetadata stores community on the chunk and renders the
+// report into Content, which is what retrieval hands to the chat model.
+func (c *Chunk) SetGraphCommunityMetadata(community *GraphCommunity) error {
+	stored := *community
+	stored.KnowledgeBaseID = ""
+	bytes, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	c.Metadata = JSON(bytes)
+	c.Content = community.Report()
+	return nil
+}
+
+// Report renders the community as a markdown report.
+func (g *GraphCommunity) Report() string {
+	var sb strings.Builder
+	sb.WriteString("# " + g.Title + "\n\n" + g.Summary + "\n")
+	for _, finding := range g.Findings {
+		sb.WriteString("\n- " + finding)
+	}
+	if len(g.Findings) > 0 {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// GraphStoreEnabled reports whether a store for knowledge base graphs is
+// configured: GRAPH_DRIVER=database keeps them in the application database,
+// otherwise they need Neo4j (NEO4J_ENABLE=true).
+func GraphStoreEnabled() bool {
+	if strings.ToLower(strings.TrimSpace(os.Getenv("GRAPH_DRIVER"))) == "database" {
+		return true
+	}
+	return strings.ToLower(os.Getenv("NEO4J_ENABLE")) == "true"
+}
@@ -0,0 +1,159 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GuardrailAction is what the guardrails stage does with a detection.
+type GuardrailAction string
+
+const (
+	// GuardrailActionLog only records the detection.
+	GuardrailActionLog GuardrailAction = "log"
+	// GuardrailActionRedact masks the matched text before it reaches the
+	// model.
+	GuardrailActionRedact GuardrailAction = "redact"
+	// GuardrailActionBlock refuses the question when the user input
+	// matches, and drops the chunk when retrieved content matches.
+	GuardrailActionBlock GuardrailAction = "block"
+)
+
+// GuardrailKind tells prompt-injection detections from PII detections.
+type GuardrailKind string
+
+const (
+	GuardrailKindInjection GuardrailKind = "injection"
+	GuardrailKindPII       GuardrailKind = "pii"
+)
+
+// MaxGuardrailPatterns caps the custom patterns of each kind.
+const MaxGuardrailPatterns = 50
+
+var guardrailPatternNameRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,31}$`)
+
+// GuardrailPattern is a named regular expression (RE2 syntax). PII matches
+// are redacted as [NAME].
+type GuardrailPattern struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+// GuardrailsConfig configures, per tenant, the pre-generation scan of the
+// user input and the retrieved chunks for prompt injection and PII.
+type GuardrailsConfig struct {
+	Enabled bool `json:"enabled"`
+	// InjectionAction applies to prompt-injection detections; defaults to
+	// block.
+	InjectionAction GuardrailAction `json:"injection_action,omitempty"`
+	// PIIAction applies to PII detections; defaults to redact.
+	PIIAction GuardrailAction `json:"pii_action,omitempty"`
+	// InjectionPatterns and PIIPatterns are checked in addition to the
+	// built-in patterns, or instead of them with DisableBuiltinPatterns.
+	InjectionPatterns      []GuardrailPattern `json:"injection_patterns,omitempty"`
+	PIIPatterns            []GuardrailPattern `json:"pii_patterns,omitempty"`
+	DisableBuiltinPatterns bool               `json:"disable_builtin_patterns,omitempty"`
+	// ClassifierModelID, when set, is a chat model asked whether the user
+	// input is a prompt-injection attempt that no pattern matched.
+	ClassifierModelID string `json:"classifier_model_id,omitempty"`
+	// BlockResponse is the answer given to a blocked question; empty uses
+	// a built-in message in the session language.
+	BlockResponse string `json:"block_response,omitempty"`
+}
+
+// Active reports whether the guardrails stage should run.
+func (c *GuardrailsConfig) Active() bool {
+	return c != nil && c.Enabled
+}
+
+// EffectiveInjectionAction returns the injection action with its default.
+func (c *GuardrailsConfig) EffectiveInjectionAction() GuardrailAction {
+	if c == nil || c.InjectionAction == "" {
+		return GuardrailActionBlock
+	}
+	return c.InjectionAction
+}
+
+// EffectivePIIAction returns the PII action with its default.
+func (c *GuardrailsConfig) EffectivePIIAction() GuardrailAction {
+	if c == nil || c.PIIAction == "" {
+		return GuardrailActionRedact
+	}
+	return c.PIIAction
+}
+
+// Validate checks the actions and that every custom pattern is named and
+// compiles.
+func (c *GuardrailsConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	for field, action := range map[string]GuardrailAction{
+		"injection_action": c.InjectionAction,
+		"pii_action":       c.PIIAction,
+	} {
+		switch action {
+		case "", GuardrailActionLog, GuardrailActionRedact, GuardrailActionBlock:
+		default:
+			return fmt.Errorf("%s must be one of log, redact, block", field)
+		}
+	}
+	for field, patterns := range map[string][]GuardrailPattern{
+		"injection_patterns": c.InjectionPatterns,
+		"pii_patterns":       c.PIIPatterns,
+	} {
+		if len(patterns) > MaxGuardrailPatterns {
+			return fmt.Errorf("%s accepts at most %d patterns", field, MaxGuardrailPatterns)
+		}
+		for _, p := range patterns {
+			if !guardrailPatternNameRe.MatchString(p.Name) {
+				return fmt.Errorf("%s: invalid pattern name %q", field, p.Name)
+			}
+			if strings.TrimSpace(p.Pattern) == "" {
+				return fmt.Errorf("%s: pattern %s is empty", field, p.Name)
+			}
+			if _, err := regexp.Compile(p.Pattern); err != nil {
+				return fmt.Errorf("%s: pattern %s: %v", field, p.Name, err)
+			}
+		}
+	}
+	if c.Enabled && c.DisableBuiltinPatterns && len(c.InjectionPatterns) == 0 &&
+		len(c.PIIPatterns) == 0 && c.ClassifierModelID == "" {
+		return errors.New("disable_builtin_patterns requires custom patterns or a classifier model")
+	}
+	return nil
+}
+
+// GuardrailDetection records one rule that matched. The matched text itself
+// is never recorded, since it may be the PII being filtered.
+type GuardrailDetection struct {
+	Kind GuardrailKind `json:"kind"`
+	// Name is the pattern name, or "llm_classifier".
+	Name string `json:"name"`
+	// Source is "query" for the user input or "chunk" for retrieved content.
+	Source  string          `json:"source"`
+	ChunkID string          `json:"chunk_id,omitempty"`
+	Action  GuardrailAction `json:"action"`
+	Count   int             `json:"count"`
+}
+
+// Value implements the driver.Valuer interface for database serialization
+func (c GuardrailsConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (c *GuardrailsConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
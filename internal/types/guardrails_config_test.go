@@ -0,0 +1,42 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuardrailsConfig_Validate(t *testing.T) {
+	var nilCfg *GuardrailsConfig
+	assert.NoError(t, nilCfg.Validate())
+	assert.False(t, nilCfg.Active())
+
+	valid := &GuardrailsConfig{
+		Enabled:         true,
+		InjectionAction: GuardrailActionLog,
+		PIIPatterns:     []GuardrailPattern{{Name: "employee_id", Pattern: `E\d{6}`}},
+	}
+	assert.NoError(t, valid.Validate())
+	assert.True(t, valid.Active())
+
+	for name, cfg := range map[string]*GuardrailsConfig{
+		"bad action":   {PIIAction: "drop"},
+		"bad regex":    {PIIPatterns: []GuardrailPattern{{Name: "x", Pattern: `(`}}},
+		"bad name":     {InjectionPatterns: []GuardrailPattern{{Name: "has space", Pattern: `x`}}},
+		"empty regex":  {PIIPatterns: []GuardrailPattern{{Name: "x", Pattern: " "}}},
+		"no detectors": {Enabled: true, DisableBuiltinPatterns: true},
+	} {
+		assert.Error(t, cfg.Validate(), name)
+	}
+}
+
+func TestGuardrailsConfig_defaults(t *testing.T) {
+	cfg := &GuardrailsConfig{}
+	assert.Equal(t, GuardrailActionBlock, cfg.EffectiveInjectionAction())
+	assert.Equal(t, GuardrailActionRedact, cfg.EffectivePIIAction())
+
+	cfg.InjectionAction = GuardrailActionLog
+	cfg.PIIAction = GuardrailActionBlock
+	assert.Equal(t, GuardrailActionLog, cfg.EffectiveInjectionAction())
+	assert.Equal(t, GuardrailActionBlock, cfg.EffectivePIIAction())
+}
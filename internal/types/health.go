@@ -0,0 +1,93 @@
+package types
+
+import "time"
+
+// HealthStatus is the state of the server or of one of its dependencies
+type HealthStatus string
+
+const (
+	// HealthStatusOK means the dependency answered in time
+	HealthStatusOK HealthStatus = "ok"
+	// HealthStatusDegraded means the dependency answered but cannot serve
+	// everything, e.g. Milvus collections that are not loaded yet
+	HealthStatusDegraded HealthStatus = "degraded"
+	// HealthStatusDown means the dependency could not be reached
+	HealthStatusDown HealthStatus = "down"
+	// HealthStatusDisabled means the dependency is not configured
+	HealthStatusDisabled HealthStatus = "disabled"
+)
+
+// Dependency kinds reported in DependencyHealth.Kind
+const (
+	DependencyDatabase      = "database"
+	DependencyCache         = "cache"
+	DependencyVectorStore   = "vector_store"
+	DependencyGraphDatabase = "graph_database"
+	DependencyObjectStorage = "object_storage"
+	DependencyModel         = "model"
+)
+
+// DependencyHealth is the result of probing one dependency
+type DependencyHealth struct {
+	Name      string       `json:"name"`
+	Kind      string       `json:"kind"`
+	Status    HealthStatus `json:"status"`
+	LatencyMs int64        `json:"latency_ms"`
+	// Critical dependencies take the whole server down when they are down;
+	// without the others the server keeps running with fewer features
+	Critical bool   `json:"critical"`
+	Message  string `json:"message,omitempty"`
+	// Collections is the load state of each Milvus collection
+	Collections []CollectionLoadState `json:"collections,omitempty"`
+	// Model is set for model endpoints
+	Model *ModelEndpointHealth `json:"model,omitempty"`
+}
+
+// ModelEndpointHealth identifies the model whose endpoint was probed.
+// Endpoint is empty for built-in models, whose address is not disclosed.
+type ModelEndpointHealth struct {
+	ID       string      `json:"id"`
+	Type     ModelType   `json:"type"`
+	Source   ModelSource `json:"source"`
+	Endpoint string      `json:"endpoint,omitempty"`
+}
+
+// SystemHealth aggregates the health of every dependency
+type SystemHealth struct {
+	Status HealthStatus `json:"status"`
+	// Degraded lists the dependencies that are down or degraded, for the
+	// frontend to disable the features relying on them
+	Degraded     []string            `json:"degraded"`
+	CheckedAt    time.Time           `json:"checked_at"`
+	Dependencies []*DependencyHealth `json:"dependencies"`
+}
+
+// NewSystemHealth aggregates dependency results. The server is down when a
+// critical dependency is down and degraded when any other dependency is
+// down or degraded.
+func NewSystemHealth(dependencies []*DependencyHealth, checkedAt time.Time) *SystemHealth {
+	health := &SystemHealth{
+		Status:       HealthStatusOK,
+		Degraded:     []string{},
+		CheckedAt:    checkedAt,
+		Dependencies: dependencies,
+	}
+	for _, dep := range dependencies {
+		switch dep.Status {
+		case HealthStatusDown:
+			if dep.Critical {
+				health.Status = HealthStatusDown
+			} else if health.Status == HealthStatusOK {
+				health.Status = HealthStatusDegraded
+			}
+		case HealthStatusDegraded:
+			if health.Status == HealthStatusOK {
+				health.Status = HealthStatusDegraded
+			}
+		default:
+			continue
+		}
+		health.Degraded = append(health.Degraded, dep.Name)
+	}
+	return health
+}
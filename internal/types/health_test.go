@@ -0,0 +1,29 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSystemHealth(t *testing.T) {
+	now := time.Now()
+	postgres := &DependencyHealth{Name: "postgres", Status: HealthStatusOK, Critical: true}
+	neo4j := &DependencyHealth{Name: "neo4j", Status: HealthStatusDisabled}
+
+	health := NewSystemHealth([]*DependencyHealth{postgres, neo4j}, now)
+	assert.Equal(t, HealthStatusOK, health.Status)
+	assert.Empty(t, health.Degraded, "disabled dependencies do not degrade the server")
+
+	milvus := &DependencyHealth{Name: "milvus", Status: HealthStatusDegraded}
+	model := &DependencyHealth{Name: "qwen3", Status: HealthStatusDown}
+	health = NewSystemHealth([]*DependencyHealth{postgres, milvus, model}, now)
+	assert.Equal(t, HealthStatusDegraded, health.Status)
+	assert.Equal(t, []string{"milvus", "qwen3"}, health.Degraded)
+
+	redis := &DependencyHealth{Name: "redis", Status: HealthStatusDown, Critical: true}
+	health = NewSystemHealth([]*DependencyHealth{milvus, redis, model}, now)
+	assert.Equal(t, HealthStatusDown, health.Status)
+	assert.Equal(t, []string{"milvus", "redis", "qwen3"}, health.Degraded)
+}
@@ -0,0 +1,227 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// Ingestion job states. An ingestion job is the per-knowledge view of the
+// document:process pipeline: the asynq task is the durable unit of work and
+// the processing spans of the current attempt are its durable progress
+// records, so the job is derived from both rather than stored separately.
+const (
+	IngestionJobQueued    = "queued"
+	IngestionJobParsing   = "parsing"   // docreader + chunking
+	IngestionJobEmbedding = "embedding" // embedding + multimodal
+	IngestionJobIndexing  = "indexing"  // postprocess + finalizing
+	IngestionJobCompleted = "completed"
+	IngestionJobFailed    = "failed"
+	IngestionJobCancelled = "cancelled"
+)
+
+// IngestPriority selects the queue lane a document:process task runs in.
+// Interactive work (a user uploading or reparsing a document) must not wait
+// behind bulk work (data source syncs, batch reparse, archive imports).
+type IngestPriority string
+
+const (
+	IngestPriorityInteractive IngestPriority = "interactive"
+	IngestPriorityBulk        IngestPriority = "bulk"
+)
+
+// Queue returns the asynq queue for the priority lane.
+func (p IngestPriority) Queue() string {
+	if p == IngestPriorityBulk {
+		return QueueBulk
+	}
+	return QueueDefault
+}
+
+// IngestPriorityForQueue maps the queue a task was dequeued from back to
+// its priority lane.
+func IngestPriorityForQueue(queue string) IngestPriority {
+	if queue == QueueBulk {
+		return IngestPriorityBulk
+	}
+	return IngestPriorityInteractive
+}
+
+// WithIngestPriority marks every document:process task enqueued with ctx as
+// belonging to the given lane.
+func WithIngestPriority(ctx context.Context, p IngestPriority) context.Context {
+	return context.WithValue(ctx, IngestPriorityContextKey, p)
+}
+
+// IngestPriorityFromContext returns the lane set by WithIngestPriority,
+// defaulting to interactive.
+func IngestPriorityFromContext(ctx context.Context) IngestPriority {
+	if p, ok := ctx.Value(IngestPriorityContextKey).(IngestPriority); ok && p != "" {
+		return p
+	}
+	return IngestPriorityInteractive
+}
+
+// ingestionStageWeights is the share of the total progress each stage
+// accounts for. DocReader and embedding dominate wall time for typical
+// documents; the weights add up to 100.
+var ingestionStageWeights = map[string]int{
+	StageDocReader:   30,
+	StageChunking:    10,
+	StageEmbedding:   30,
+	StageMultimodal:  10,
+	StagePostProcess: 20,
+}
+
+// ingestionStageStates maps a pipeline stage to the job state reported
+// while it runs.
+var ingestionStageStates = map[string]string{
+	StageDocReader:   IngestionJobParsing,
+	StageChunking:    IngestionJobParsing,
+	StageEmbedding:   IngestionJobEmbedding,
+	StageMultimodal:  IngestionJobEmbedding,
+	StagePostProcess: IngestionJobIndexing,
+}
+
+// IngestionJob is the progress view of a knowledge's current ingestion.
+type IngestionJob struct {
+	KnowledgeID     string `json:"knowledge_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	Attempt         int    `json:"attempt"`
+	State           string `json:"state"`
+	// Stage is the pipeline stage currently running, empty while queued or
+	// once the job is terminal.
+	Stage    string         `json:"stage,omitempty"`
+	Progress int            `json:"progress"`
+	Priority IngestPriority `json:"priority"`
+	// Retry is the number of automatic retries the current attempt has
+	// used; retries back off exponentially between runs.
+	Retry        int        `json:"retry"`
+	ErrorCode    string     `json:"error_code,omitempty"`
+	ErrorMessage string     `json:"error_message,omitempty"`
+	StartedAt    *time.Time `json:"started_at,omitempty"`
+	FinishedAt   *time.Time `json:"finished_at,omitempty"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Terminal reports whether the job will not change without a new attempt.
+func (j *IngestionJob) Terminal() bool {
+	switch j.State {
+	case IngestionJobCompleted, IngestionJobFailed, IngestionJobCancelled:
+		return true
+	}
+	return false
+}
+
+// BuildIngestionJob derives the job view from the knowledge row and the
+// spans of its latest attempt. rows may be empty (lite mode, or knowledge
+// parsed before span tracking existed); the state then follows
+// parse_status alone.
+func BuildIngestionJob(k *Knowledge, attempt int, rows []KnowledgeProcessingSpan) *IngestionJob {
+	job := &IngestionJob{
+		KnowledgeID:     k.ID,
+		KnowledgeBaseID: k.KnowledgeBaseID,
+		Attempt:         attempt,
+		Priority:        IngestPriorityInteractive,
+		UpdatedAt:       k.UpdatedAt,
+	}
+
+	stages := make(map[string]*KnowledgeProcessingSpan, len(AllStages))
+	var lastFailure *KnowledgeProcessingSpan
+	for i := range rows {
+		r := &rows[i]
+		switch r.Kind {
+		case SpanKindRoot:
+			job.StartedAt = r.StartedAt
+			job.FinishedAt = r.FinishedAt
+		case SpanKindStage:
+			stages[r.Name] = r
+		}
+		if r.Status == SpanStatusFailed {
+			lastFailure = r
+		}
+		if r.UpdatedAt.After(job.UpdatedAt) {
+			job.UpdatedAt = r.UpdatedAt
+		}
+	}
+	if doc := stages[StageDocReader]; doc != nil {
+		if q, ok := doc.Input["queue"].(string); ok {
+			job.Priority = IngestPriorityForQueue(q)
+		}
+		job.Retry = jsonInt(doc.Input["retry"])
+	}
+
+	for _, name := range AllStages {
+		s := stages[name]
+		if s == nil {
+			continue
+		}
+		switch s.Status {
+		case SpanStatusDone, SpanStatusSkipped:
+			job.Progress += ingestionStageWeights[name]
+		case SpanStatusRunning:
+			job.Progress += ingestionStageWeights[name] / 2
+			if job.Stage == "" {
+				job.Stage = name
+			}
+		}
+	}
+
+	switch k.ParseStatus {
+	case ParseStatusCompleted:
+		job.State = IngestionJobCompleted
+		job.Stage = ""
+		job.Progress = 100
+		return job
+	case ParseStatusFailed, ParseStatusQuarantined:
+		job.State = IngestionJobFailed
+		job.Stage = ""
+		job.ErrorMessage = k.ErrorMessage
+		if lastFailure != nil {
+			job.ErrorCode = lastFailure.ErrorCode
+			if job.ErrorMessage == "" {
+				job.ErrorMessage = lastFailure.ErrorMessage
+			}
+		}
+		return job
+	case ParseStatusCancelled, ParseStatusDeleting:
+		job.State = IngestionJobCancelled
+		job.Stage = ""
+		return job
+	case ParseStatusFinalizing:
+		job.State = IngestionJobIndexing
+	case ParseStatusProcessing:
+		job.State = ingestionStageStates[job.Stage]
+		if job.State == "" {
+			// Between stages, or waiting for a retry: report the first
+			// stage that has not finished yet.
+			job.State = IngestionJobParsing
+			for _, name := range AllStages {
+				if s := stages[name]; s == nil ||
+					(s.Status != SpanStatusDone && s.Status != SpanStatusSkipped) {
+					job.State = ingestionStageStates[name]
+					break
+				}
+			}
+		}
+	default:
+		job.State = IngestionJobQueued
+	}
+	if job.Progress > 99 {
+		job.Progress = 99
+	}
+	return job
+}
+
+// jsonInt reads a number out of a JSONMap value, which is an int before the
+// span is persisted and a float64 after a database round trip.
+func jsonInt(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package types
+
+import (
+	"context"
+	"testing"
+)
+
+func stageSpan(name, status string) KnowledgeProcessingSpan {
+	return KnowledgeProcessingSpan{Name: name, Kind: SpanKindStage, Status: status}
+}
+
+func TestBuildIngestionJob(t *testing.T) {
+	doc := stageSpan(StageDocReader, SpanStatusDone)
+	doc.Input = JSONMap{"queue": QueueBulk, "retry": float64(2)}
+
+	tests := []struct {
+		name         string
+		status       string
+		rows         []KnowledgeProcessingSpan
+		wantState    string
+		wantStage    string
+		wantProgress int
+	}{
+		{"queued before any stage", ParseStatusPending, nil, IngestionJobQueued, "", 0},
+		{"parsing", ParseStatusProcessing,
+			[]KnowledgeProcessingSpan{stageSpan(StageDocReader, SpanStatusRunning)},
+			IngestionJobParsing, StageDocReader, 15},
+		{"embedding", ParseStatusProcessing, []KnowledgeProcessingSpan{
+			doc, stageSpan(StageChunking, SpanStatusDone),
+			stageSpan(StageEmbedding, SpanStatusRunning), stageSpan(StageMultimodal, SpanStatusSkipped),
+		}, IngestionJobEmbedding, StageEmbedding, 65},
+		{"between stages", ParseStatusProcessing, []KnowledgeProcessingSpan{
+			doc, stageSpan(StageChunking, SpanStatusDone), stageSpan(StageEmbedding, SpanStatusDone),
+			stageSpan(StageMultimodal, SpanStatusDone),
+		}, IngestionJobIndexing, "", 80},
+		{"finalizing never reports 100", ParseStatusFinalizing, []KnowledgeProcessingSpan{
+			doc, stageSpan(StageChunking, SpanStatusDone), stageSpan(StageEmbedding, SpanStatusDone),
+			stageSpan(StageMultimodal, SpanStatusDone), stageSpan(StagePostProcess, SpanStatusDone),
+		}, IngestionJobIndexing, "", 99},
+		{"completed without spans", ParseStatusCompleted, nil, IngestionJobCompleted, "", 100},
+		{"cancelled", ParseStatusCancelled,
+			[]KnowledgeProcessingSpan{stageSpan(StageDocReader, SpanStatusRunning)},
+			IngestionJobCancelled, "", 15},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k := &Knowledge{ID: "k1", KnowledgeBaseID: "kb1", ParseStatus: tt.status}
+			job := BuildIngestionJob(k, 1, tt.rows)
+			if job.State != tt.wantState || job.Stage != tt.wantStage || job.Progress != tt.wantProgress {
+				t.Errorf("job = %s/%s/%d, want %s/%s/%d",
+					job.State, job.Stage, job.Progress, tt.wantState, tt.wantStage, tt.wantProgress)
+			}
+		})
+	}
+}
+
+func TestBuildIngestionJob_FailureAndPriority(t *testing.T) {
+	doc := stageSpan(StageDocReader, SpanStatusFailed)
+	doc.Input = JSONMap{"queue": QueueBulk, "retry": 3}
+	doc.ErrorCode = "DOCREADER_TIMEOUT"
+	doc.ErrorMessage = "docreader timed out"
+	k := &Knowledge{ID: "k1", ParseStatus: ParseStatusFailed}
+
+	job := BuildIngestionJob(k, 2, []KnowledgeProcessingSpan{doc})
+	if job.State != IngestionJobFailed || !job.Terminal() {
+		t.Fatalf("state = %s, want terminal failed", job.State)
+	}
+	if job.ErrorCode != "DOCREADER_TIMEOUT" || job.ErrorMessage != "docreader timed out" {
+		t.Errorf("error = %s %q", job.ErrorCode, job.ErrorMessage)
+	}
+	if job.Priority != IngestPriorityBulk || job.Retry != 3 || job.Attempt != 2 {
+		t.Errorf("priority/retry/attempt = %s/%d/%d", job.Priority, job.Retry, job.Attempt)
+	}
+}
+
+func TestIngestPriorityFromContext(t *testing.T) {
+	if p := IngestPriorityFromContext(context.Background()); p != IngestPriorityInteractive || p.Queue() != QueueDefault {
+		t.Errorf("default priority = %s (%s)", p, p.Queue())
+	}
+	ctx := WithIngestPriority(context.Background(), IngestPriorityBulk)
+	if p := IngestPriorityFromContext(ctx); p != IngestPriorityBulk || p.Queue() != QueueBulk {
+		t.Errorf("bulk priority = %s (%s)", p, p.Queue())
+	}
+}
@@ -0,0 +1,47 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// AnalyticsService logs answered chat queries and aggregates them for the
+// admin dashboard
+type AnalyticsService interface {
+	// RecordQuery logs a completed answer of the caller's tenant and records
+	// its knowledge gaps when it retrieved nothing. It never fails the answer.
+	RecordQuery(ctx context.Context, record *types.QueryRecord)
+
+	// GetOverview returns the query, zero-result and latency totals of the
+	// caller's tenant with the per-day counts
+	GetOverview(ctx context.Context, query *types.AnalyticsQuery) (*types.QueryAnalyticsOverview, error)
+	// TopQueries returns the most asked queries
+	TopQueries(ctx context.Context, query *types.AnalyticsQuery) ([]*types.QueryStat, error)
+	// ZeroResultQueries returns the queries that most often retrieved nothing
+	ZeroResultQueries(ctx context.Context, query *types.AnalyticsQuery) ([]*types.QueryStat, error)
+	// TopDocuments returns the documents answers cited most
+	TopDocuments(ctx context.Context, query *types.AnalyticsQuery) ([]*types.DocumentStat, error)
+	// KnowledgeBaseUsage returns the queries per knowledge base and day,
+	// most used knowledge base first
+	KnowledgeBaseUsage(ctx context.Context, query *types.AnalyticsQuery) ([]*types.KnowledgeBaseUsage, error)
+}
+
+// AnalyticsRepository stores query logs and aggregates them
+type AnalyticsRepository interface {
+	// SaveQueryLog stores a query log with its knowledge bases and documents
+	SaveQueryLog(ctx context.Context, log *types.QueryLog) error
+	// CountByDay counts the queries per day, in day order
+	CountByDay(ctx context.Context, query *types.AnalyticsQuery) ([]*types.QueryDayCounts, error)
+	// LatencyPercentiles returns the answer latency at each percentile
+	LatencyPercentiles(
+		ctx context.Context, query *types.AnalyticsQuery, percentiles []int,
+	) ([]*types.LatencyPercentile, error)
+	// TopQueries returns the most asked queries, or those that most often
+	// retrieved nothing when zeroResultOnly is set
+	TopQueries(ctx context.Context, query *types.AnalyticsQuery, zeroResultOnly bool) ([]*types.QueryStat, error)
+	// TopDocuments returns the documents answers cited most, then retrieved most
+	TopDocuments(ctx context.Context, query *types.AnalyticsQuery) ([]*types.DocumentStat, error)
+	// CountByKnowledgeBase counts the queries per knowledge base and day
+	CountByKnowledgeBase(ctx context.Context, query *types.AnalyticsQuery) ([]*types.KnowledgeBaseUsage, error)
+}
@@ -0,0 +1,46 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// APIKeyService manages the API keys of a tenant and authenticates requests
+// made with them.
+type APIKeyService interface {
+	// ListAPIKeys lists the API keys of the caller's tenant.
+	ListAPIKeys(ctx context.Context) ([]*types.APIKey, error)
+	// CreateAPIKey creates an API key and returns it with its secret.
+	CreateAPIKey(ctx context.Context, req *types.APIKeyRequest) (*types.APIKeyWithSecret, error)
+	// UpdateAPIKey replaces the name, role, scopes, knowledge bases and
+	// expiry of an API key. The secret is unchanged.
+	UpdateAPIKey(ctx context.Context, id string, req *types.APIKeyRequest) (*types.APIKey, error)
+	// RotateAPIKey replaces the secret of an API key; the old secret stops
+	// working at once.
+	RotateAPIKey(ctx context.Context, id string) (*types.APIKeyWithSecret, error)
+	// RevokeAPIKey revokes an API key.
+	RevokeAPIKey(ctx context.Context, id string) error
+	// Authenticate returns the live API key with the secret, or an error
+	// when the secret is unknown, revoked or expired.
+	Authenticate(ctx context.Context, secret string) (*types.APIKey, error)
+}
+
+// APIKeyRepository persists API keys.
+type APIKeyRepository interface {
+	// Create creates an API key.
+	Create(ctx context.Context, key *types.APIKey) error
+	// GetByID gets an API key of a tenant.
+	GetByID(ctx context.Context, tenantID uint64, id string) (*types.APIKey, error)
+	// GetByHash gets the API key whose secret has the hash.
+	GetByHash(ctx context.Context, hash string) (*types.APIKey, error)
+	// ListByTenant lists the API keys of a tenant, newest first.
+	ListByTenant(ctx context.Context, tenantID uint64) ([]*types.APIKey, error)
+	// Update saves the settings and secret hash of an API key.
+	Update(ctx context.Context, key *types.APIKey) error
+	// TouchLastUsed records when an API key was last used.
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+	// Delete revokes an API key of a tenant.
+	Delete(ctx context.Context, tenantID uint64, id string) error
+}
@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/hibiken/asynq"
+)
+
+// AutoTagService classifies the documents of a knowledge base against the
+// knowledge base's tags and attaches the matching tags.
+type AutoTagService interface {
+	// RetagKnowledgeBase enqueues auto-tagging of the given documents of the
+	// knowledge base, or of all its documents when knowledgeIDs is empty.
+	RetagKnowledgeBase(ctx context.Context, kbID string, knowledgeIDs []string) error
+	// ProcessAutoTag handles the auto-tagging task.
+	ProcessAutoTag(ctx context.Context, t *asynq.Task) error
+}
@@ -30,6 +30,8 @@ type ChunkRepository interface {
 	ListChunksBySeqID(ctx context.Context, tenantID uint64, seqIDs []int64) ([]*types.Chunk, error)
 	// ListChunksByKnowledgeID lists chunks by knowledge id
 	ListChunksByKnowledgeID(ctx context.Context, tenantID uint64, knowledgeID string) ([]*types.Chunk, error)
+	// ListChunksByKnowledgeIndices lists the enabled text chunks of a knowledge id at the given chunk indices
+	ListChunksByKnowledgeIndices(ctx context.Context, tenantID uint64, knowledgeID string, indices []int) ([]*types.Chunk, error)
 	// ListPagedChunksByKnowledgeID lists paged chunks by knowledge id.
 	// When tagID is non-empty, results are filtered by tag_id.
 	// knowledgeType: "faq" or "manual" - determines sort order and search behavior
@@ -56,6 +58,9 @@ type ChunkRepository interface {
 	UpdateChunk(ctx context.Context, chunk *types.Chunk) error
 	// UpdateChunks updates chunks in batch
 	UpdateChunks(ctx context.Context, chunks []*types.Chunk) error
+	// UpdateChunkPositions updates chunk_index, start_at, end_at and the
+	// prev/next links of chunks, leaving their content and metadata untouched
+	UpdateChunkPositions(ctx context.Context, tenantID uint64, chunks []*types.Chunk) error
 	// DeleteChunk deletes a chunk
 	DeleteChunk(ctx context.Context, tenantID uint64, id string) error
 	// DeleteChunks deletes chunks by IDs in batch
@@ -68,6 +73,9 @@ type ChunkRepository interface {
 	ListImageInfoByKnowledgeIDs(ctx context.Context, tenantID uint64, knowledgeIDs []string) ([]ChunkImageInfo, error)
 	// MoveChunksByKnowledgeID updates knowledge_base_id for all chunks of a knowledge item
 	MoveChunksByKnowledgeID(ctx context.Context, tenantID uint64, knowledgeID string, targetKBID string) error
+	// ListChunkIDsByIndexGeneration lists the IDs of the chunks a knowledge base has
+	// staged for the given index generation
+	ListChunkIDsByIndexGeneration(ctx context.Context, tenantID uint64, kbID string, generation int64) ([]string, error)
 	// DeleteChunksByTagID deletes all chunks with the specified tag ID
 	// Returns the IDs of deleted chunks for index cleanup
 	DeleteChunksByTagID(ctx context.Context, tenantID uint64, kbID string, tagID string, excludeIDs []string) ([]string, error)
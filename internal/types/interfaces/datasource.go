@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"net/http"
 
 	"github.com/Tencent/WeKnora/internal/types"
 	"github.com/hibiken/asynq"
@@ -56,6 +57,11 @@ type DataSourceService interface {
 	// ManualSync triggers an immediate sync for a data source
 	ManualSync(ctx context.Context, dsID string) (*types.SyncLog, error)
 
+	// HandleWebhook verifies a change notification pushed by the source of a
+	// data source and triggers a sync when it announces changes. It reports
+	// whether a sync was triggered.
+	HandleWebhook(ctx context.Context, dsID string, header http.Header, body []byte) (bool, error)
+
 	// PauseDataSource pauses a data source's scheduled syncs
 	PauseDataSource(ctx context.Context, id string) error
 
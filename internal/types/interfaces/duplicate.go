@@ -0,0 +1,32 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// DuplicateReportRepository stores the duplicate analysis report of each
+// knowledge base
+type DuplicateReportRepository interface {
+	// GetReport returns the report of a knowledge base, or nil when it was
+	// never analyzed
+	GetReport(ctx context.Context, tenantID uint64, knowledgeBaseID string) (*types.DuplicateReport, error)
+	// SaveReport creates or replaces the report of a knowledge base
+	SaveReport(ctx context.Context, report *types.DuplicateReport) error
+}
+
+// DuplicateService finds exact and near duplicate content in a knowledge base
+// and removes the redundant copies.
+type DuplicateService interface {
+	// AnalyzeDuplicates enqueues a duplicate analysis of the knowledge base
+	// and returns its running report.
+	AnalyzeDuplicates(ctx context.Context, kbID string, req *types.DuplicateAnalysisRequest) (*types.DuplicateReport, error)
+	// GetDuplicateReport returns the latest duplicate report of the knowledge base.
+	GetDuplicateReport(ctx context.Context, kbID string) (*types.DuplicateReport, error)
+	// RemoveDuplicates disables or deletes the redundant copies of the latest report.
+	RemoveDuplicates(ctx context.Context, kbID string, req *types.DuplicateRemoveRequest) (*types.DuplicateRemoveResult, error)
+	// ProcessDuplicateAnalysis handles the duplicate analysis task.
+	ProcessDuplicateAnalysis(ctx context.Context, t *asynq.Task) error
+}
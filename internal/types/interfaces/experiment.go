@@ -0,0 +1,65 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// ExperimentService runs alternative pipeline configurations in the shadow
+// of production traffic and reports how their answers compare
+type ExperimentService interface {
+	// CreateExperiment creates a running experiment in the caller's tenant
+	CreateExperiment(ctx context.Context, req *types.ExperimentRequest) (*types.Experiment, error)
+	// ListExperiments returns the experiments of the caller's tenant with
+	// their reports, newest first
+	ListExperiments(ctx context.Context) ([]*types.Experiment, error)
+	// GetExperiment returns an experiment with its report
+	GetExperiment(ctx context.Context, id string) (*types.Experiment, error)
+	// UpdateExperiment renames, resizes, pauses or resumes an experiment
+	UpdateExperiment(ctx context.Context, id string, req *types.ExperimentUpdateRequest) (*types.Experiment, error)
+	// DeleteExperiment deletes an experiment with its samples
+	DeleteExperiment(ctx context.Context, id string) error
+	// ListSamples returns a page of the samples of an experiment, newest
+	// first; winner filters them by verdict, "failed" for samples without one
+	ListSamples(ctx context.Context, id string, winner string, page *types.Pagination) (*types.PageResult, error)
+
+	// ObserveAnswer offers a completed production answer to the running
+	// experiments of the caller's tenant and enqueues a shadow answer for
+	// each experiment that samples it. It never fails the answer.
+	ObserveAnswer(ctx context.Context, observation *types.ExperimentObservation)
+	// ProcessShadowAnswer handles the shadow answer task
+	ProcessShadowAnswer(ctx context.Context, t *asynq.Task) error
+}
+
+// ExperimentRepository stores shadow experiments and their samples
+type ExperimentRepository interface {
+	// CreateExperiment creates an experiment
+	CreateExperiment(ctx context.Context, experiment *types.Experiment) error
+	// GetExperiment returns an experiment of a tenant, or nil
+	GetExperiment(ctx context.Context, tenantID uint64, id string) (*types.Experiment, error)
+	// ListExperiments returns the experiments of a tenant, newest first
+	ListExperiments(ctx context.Context, tenantID uint64) ([]*types.Experiment, error)
+	// ListRunningExperiments returns the running experiments of a tenant
+	ListRunningExperiments(ctx context.Context, tenantID uint64) ([]*types.Experiment, error)
+	// UpdateExperiment saves the settings and state of an experiment
+	UpdateExperiment(ctx context.Context, experiment *types.Experiment) error
+	// ReserveSample takes one sample of a running experiment's budget,
+	// completing the experiment when it takes the last one. It reports
+	// false when the experiment is not running or its budget is used up.
+	ReserveSample(ctx context.Context, tenantID uint64, id string) (bool, error)
+	// DeleteExperiment deletes an experiment of a tenant with its samples
+	DeleteExperiment(ctx context.Context, tenantID uint64, id string) error
+
+	// SaveSample stores a sample, replacing an earlier one of the same
+	// experiment and message
+	SaveSample(ctx context.Context, sample *types.ExperimentSample) error
+	// ListSamples returns a page of the samples of an experiment, of one
+	// verdict when winner is not nil, newest first, and their total
+	ListSamples(
+		ctx context.Context, experimentID string, winner *types.ExperimentWinner, page *types.Pagination,
+	) ([]*types.ExperimentSample, int64, error)
+	// CountSamples counts the samples of experiments by verdict
+	CountSamples(ctx context.Context, experimentIDs []string) ([]*types.ExperimentSampleCounts, error)
+}
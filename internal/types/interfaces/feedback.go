@@ -0,0 +1,49 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// FeedbackService records users' feedback on answers and aggregates and
+// exports it for tuning retrieval
+type FeedbackService interface {
+	// SubmitFeedback creates or replaces the caller's feedback on an assistant message
+	SubmitFeedback(
+		ctx context.Context, sessionID, messageID string, req *types.MessageFeedbackRequest,
+	) (*types.MessageFeedback, error)
+	// GetFeedback returns the caller's feedback on a message, or nil
+	GetFeedback(ctx context.Context, sessionID, messageID string) (*types.MessageFeedback, error)
+	// DeleteFeedback withdraws the caller's feedback on a message
+	DeleteFeedback(ctx context.Context, sessionID, messageID string) error
+	// GetStats aggregates the feedback of the caller's tenant
+	GetStats(ctx context.Context, query *types.FeedbackQuery) (*types.FeedbackStats, error)
+	// Export returns the feedback of the caller's tenant as JSON lines in the given format
+	Export(ctx context.Context, query *types.FeedbackQuery, format types.FeedbackExportFormat) ([]byte, error)
+}
+
+// FeedbackRepository stores message feedback with its source snapshots
+type FeedbackRepository interface {
+	// SaveFeedback creates or updates a feedback and replaces its sources
+	SaveFeedback(ctx context.Context, feedback *types.MessageFeedback) error
+	// GetFeedback returns a user's feedback on a message, or nil
+	GetFeedback(ctx context.Context, tenantID uint64, messageID, userID string) (*types.MessageFeedback, error)
+	// DeleteFeedback deletes a user's feedback on a message with its sources
+	DeleteFeedback(ctx context.Context, tenantID uint64, messageID, userID string) error
+	// CountByDay counts the feedback per day
+	CountByDay(ctx context.Context, query *types.FeedbackQuery) ([]*types.FeedbackDayCounts, error)
+	// CountSourcesByKnowledgeBase counts the sources of the feedback per knowledge base
+	CountSourcesByKnowledgeBase(
+		ctx context.Context, query *types.FeedbackQuery,
+	) ([]*types.FeedbackKnowledgeBaseCounts, error)
+	// CountSourcesByScoreBucket counts the sources of the feedback per score bucket
+	CountSourcesByScoreBucket(ctx context.Context, query *types.FeedbackQuery) (map[int]types.FeedbackSourceCounts, error)
+	// TopWrongSources returns the chunks most often marked as wrong
+	TopWrongSources(ctx context.Context, query *types.FeedbackQuery, limit int) ([]*types.FeedbackWrongSource, error)
+	// ListFeedback returns up to limit feedback with their sources, ordered by
+	// ID and starting after afterID
+	ListFeedback(
+		ctx context.Context, query *types.FeedbackQuery, afterID string, limit int,
+	) ([]*types.MessageFeedback, error)
+}
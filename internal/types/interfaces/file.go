@@ -4,6 +4,7 @@ import (
 	"context"
 	"io"
 	"mime/multipart"
+	"time"
 )
 
 // FileService is the interface for file services.
@@ -19,6 +20,11 @@ type FileService interface {
 	SaveBytes(ctx context.Context, data []byte, tenantID uint64, fileName string, temp bool) (string, error)
 	// GetFile retrieves a file.
 	GetFile(ctx context.Context, filePath string) (io.ReadCloser, error)
+	// GetFileRange retrieves length bytes of a file starting at offset, or
+	// everything from offset on when length <= 0, and reports the size of the
+	// whole file (-1 if the backend does not tell). It fails with
+	// file.ErrInvalidRange when offset is at or past the end of the file.
+	GetFileRange(ctx context.Context, filePath string, offset, length int64) (io.ReadCloser, int64, error)
 	// GetFileURL returns a download URL for the file (if supported by the storage backend).
 	GetFileURL(ctx context.Context, filePath string) (string, error)
 	// DeleteFile deletes a file.
@@ -29,3 +35,51 @@ type FileService interface {
 	// when srcPath belongs to a different storage provider than this service.
 	CopyFile(ctx context.Context, srcPath string, tenantID uint64, knowledgeID string) (string, error)
 }
+
+// PresignedUploader is implemented by file services that can hand out
+// presigned upload URLs, letting clients PUT an object straight to the
+// storage backend instead of streaming it through the server.
+type PresignedUploader interface {
+	// GetUploadURL returns a time-limited URL accepting a PUT of the object
+	// at filePath. When contentType is set the upload must send it unchanged.
+	GetUploadURL(ctx context.Context, filePath, contentType string) (string, error)
+}
+
+// EncryptedFileService is implemented by file services that apply
+// server-side encryption to the objects they write.
+type EncryptedFileService interface {
+	// EncryptionKeyID identifies the key new objects are encrypted with, as
+	// recorded in Knowledge.EncryptionKeyID; "" when encryption is off.
+	EncryptionKeyID() string
+	// ReencryptFile rewrites the object at filePath in place under the
+	// current key, e.g. after the KMS key was rotated. The path is unchanged.
+	// Only meaningful while EncryptionKeyID is non-empty.
+	ReencryptFile(ctx context.Context, filePath string) error
+}
+
+// TempFileCleaner is implemented by file services that can delete expired
+// temp files (SaveBytes with temp=true) themselves. It backs up bucket
+// expiration rules, which only exist on buckets the service created, and
+// enforces tenant retentions shorter than the bucket-wide rule.
+type TempFileCleaner interface {
+	// CleanupTempFiles deletes tenantID's temp files last modified before
+	// olderThan and returns how many were deleted.
+	CleanupTempFiles(ctx context.Context, tenantID uint64, olderThan time.Time) (int, error)
+}
+
+// ExpiringURLProvider is implemented by file services whose download URLs
+// can be issued with a caller-chosen lifetime.
+type ExpiringURLProvider interface {
+	// GetFileURLWithExpiry is GetFileURL with a URL valid for expiry;
+	// expiry <= 0 uses the service's configured default.
+	GetFileURLWithExpiry(ctx context.Context, filePath string, expiry time.Duration) (string, error)
+}
+
+// DerivedFileWriter is implemented by file services that can store assets
+// derived from a stored file, such as thumbnails, next to the original.
+type DerivedFileWriter interface {
+	// SaveDerivedFile stores data under filePath's object key plus suffix
+	// (e.g. ".thumb.jpg"), in the same bucket, and returns its path, which
+	// is always filePath + suffix. An existing derived file is replaced.
+	SaveDerivedFile(ctx context.Context, filePath, suffix string, data []byte) (string, error)
+}
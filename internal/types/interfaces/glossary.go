@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// GlossaryService builds and serves per-knowledge-base glossaries of domain
+// terms and acronyms mined from the KB's own documents.
+type GlossaryService interface {
+	// BuildGlossary enqueues a glossary (re)build for the knowledge base.
+	BuildGlossary(ctx context.Context, kbID string) error
+	// ListGlossary returns the stored glossary entries of the knowledge base.
+	ListGlossary(ctx context.Context, kbID string) ([]*types.GlossaryEntry, error)
+	// MatchGlossary returns the glossary entries of the targets' knowledge
+	// bases whose term occurs verbatim in text.
+	MatchGlossary(ctx context.Context, targets types.SearchTargets, text string) ([]*types.GlossaryEntry, error)
+	// ProcessGlossaryBuild handles the glossary build task.
+	ProcessGlossaryBuild(ctx context.Context, t *asynq.Task) error
+}
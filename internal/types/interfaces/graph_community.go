@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// GraphCommunityService groups a knowledge base's entity graph into
+// communities, has the KB's summary model report on each of them and
+// retrieves the reports for global, thematic questions.
+type GraphCommunityService interface {
+	// BuildCommunities enqueues a community (re)build for the knowledge base.
+	BuildCommunities(ctx context.Context, kbID string) error
+	// ListCommunities returns the stored communities of the knowledge base,
+	// most important first.
+	ListCommunities(ctx context.Context, kbID string) ([]*types.GraphCommunity, error)
+	// SearchCommunities returns the community reports of the targets'
+	// knowledge bases that best answer query, as GraphRetrieverType results.
+	SearchCommunities(ctx context.Context, targets types.SearchTargets, query string, topK int) ([]*types.SearchResult, error)
+	// ProcessCommunityBuild handles the community build task.
+	ProcessCommunityBuild(ctx context.Context, t *asynq.Task) error
+}
@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// HealthService probes the services the server depends on, so operators
+// and load balancers can tell a broken instance from a degraded one
+type HealthService interface {
+	// CheckHealth probes every dependency and the current tenant's model
+	// endpoints in parallel
+	CheckHealth(ctx context.Context) *types.SystemHealth
+}
@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// IndexMigrationService rebuilds a knowledge base's chunks and index rows
+// (after a chunking or embedding model change) as a new index generation
+// that retrieval only sees once it is complete.
+type IndexMigrationService interface {
+	// StartIndexMigration reserves a new index generation and enqueues its
+	// build. Returns the reserved generation.
+	StartIndexMigration(ctx context.Context, kbID string, req *types.IndexMigrationRequest) (int64, error)
+	// AbortIndexMigration cancels the pending migration of the knowledge base.
+	AbortIndexMigration(ctx context.Context, kbID string) error
+	// ProcessIndexMigration handles the index migration task.
+	ProcessIndexMigration(ctx context.Context, t *asynq.Task) error
+}
@@ -0,0 +1,36 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// KBSnapshotRepository stores knowledge base snapshots and snapshot policies
+type KBSnapshotRepository interface {
+	// CreateSnapshot adds a snapshot
+	CreateSnapshot(ctx context.Context, snapshot *types.KBSnapshot) error
+	// UpdateSnapshot saves every field of a snapshot
+	UpdateSnapshot(ctx context.Context, snapshot *types.KBSnapshot) error
+	// GetSnapshot returns a snapshot of a tenant, or nil when it does not exist
+	GetSnapshot(ctx context.Context, tenantID uint64, id string) (*types.KBSnapshot, error)
+	// ListSnapshots returns the snapshots of a tenant, newest first. An empty
+	// knowledgeBaseID lists the snapshots of every knowledge base.
+	ListSnapshots(ctx context.Context, tenantID uint64, knowledgeBaseID string) ([]*types.KBSnapshot, error)
+	// DeleteSnapshot deletes a snapshot row
+	DeleteSnapshot(ctx context.Context, tenantID uint64, id string) error
+
+	// GetPolicy returns the snapshot policy of a knowledge base, or nil when
+	// none was configured
+	GetPolicy(ctx context.Context, tenantID uint64, knowledgeBaseID string) (*types.KBSnapshotPolicy, error)
+	// SavePolicy creates or replaces the snapshot policy of a knowledge base
+	SavePolicy(ctx context.Context, policy *types.KBSnapshotPolicy) error
+	// ListDuePolicies returns up to limit enabled policies due at now
+	ListDuePolicies(ctx context.Context, now time.Time, limit int) ([]*types.KBSnapshotPolicy, error)
+	// ClaimPolicy moves the next run of a policy from prev to next and
+	// reports whether this caller won the claim
+	ClaimPolicy(ctx context.Context, knowledgeBaseID string, prev, next time.Time) (bool, error)
+	// DeletePolicy deletes the snapshot policy of a knowledge base
+	DeletePolicy(ctx context.Context, knowledgeBaseID string) error
+}
@@ -53,6 +53,15 @@ type KnowledgeService interface {
 		payload *types.ManualKnowledgePayload,
 		channel string,
 	) (*types.Knowledge, error)
+	// CreateKnowledgeFromCodeArchive imports the source files of a zip archive
+	// of a code repository, one knowledge per file named by its repository path.
+	CreateKnowledgeFromCodeArchive(
+		ctx context.Context,
+		kbID string,
+		file *multipart.FileHeader,
+		tagIDs []string,
+		channel string,
+	) (*types.CodeArchiveImportResult, error)
 	// GetKnowledgeByID retrieves knowledge by ID (uses tenant from context).
 	GetKnowledgeByID(ctx context.Context, id string) (*types.Knowledge, error)
 	// GetKnowledgeByIDOnly retrieves knowledge by ID without tenant filter (for permission resolution).
@@ -87,14 +96,60 @@ type KnowledgeService interface {
 	DeleteKnowledgeList(ctx context.Context, ids []string) error
 	// GetKnowledgeFile retrieves the file associated with the knowledge.
 	GetKnowledgeFile(ctx context.Context, id string) (io.ReadCloser, string, error)
+	// GetKnowledgeFileRange retrieves length bytes of the knowledge's file
+	// starting at offset, plus its file name and total size.
+	GetKnowledgeFileRange(ctx context.Context, id string, offset, length int64) (io.ReadCloser, string, int64, error)
+	// GetKnowledgePreviewURL returns a download URL for the thumbnail of the
+	// knowledge's image or PDF file, or "" when none has been generated.
+	GetKnowledgePreviewURL(ctx context.Context, id string) (string, error)
+	// GetKnowledgePreview opens the thumbnail of the knowledge's file.
+	GetKnowledgePreview(ctx context.Context, id string) (io.ReadCloser, error)
 	// UpdateKnowledge updates knowledge information.
 	UpdateKnowledge(ctx context.Context, knowledge *types.Knowledge) error
+	// UpdateKnowledgeACL replaces the ACL of a knowledge, or removes it when acl lists nobody.
+	UpdateKnowledgeACL(ctx context.Context, id string, acl *types.KnowledgeACL) (*types.Knowledge, error)
 	// UpdateManualKnowledge updates manual Markdown knowledge content.
 	UpdateManualKnowledge(
 		ctx context.Context,
 		knowledgeID string,
 		payload *types.ManualKnowledgePayload,
 	) (*types.Knowledge, error)
+	// UpdateKnowledgeFile uploads a new version of the file of a file knowledge.
+	// Only the chunks whose content changed are re-embedded; unchanged chunks
+	// keep their IDs. metadata is merged over the existing metadata.
+	UpdateKnowledgeFile(
+		ctx context.Context,
+		knowledgeID string,
+		file *multipart.FileHeader,
+		customFileName string,
+		metadata map[string]string,
+	) (*types.Knowledge, error)
+	// ListKnowledgeVersions lists the file versions of a knowledge, newest first.
+	ListKnowledgeVersions(ctx context.Context, knowledgeID string) ([]*types.KnowledgeVersion, error)
+	// StartChunkImport uploads a JSONL file of pre-chunked (optionally pre-embedded)
+	// documents and imports it asynchronously, bypassing document parsing.
+	StartChunkImport(
+		ctx context.Context,
+		kbID string,
+		file *multipart.FileHeader,
+		batchSize, rateLimit int,
+		channel string,
+	) (*types.ChunkImportProgress, error)
+	// CreateKBSnapshot snapshots a knowledge base asynchronously; trigger is
+	// types.KBSnapshotTriggerManual or types.KBSnapshotTriggerScheduled.
+	CreateKBSnapshot(ctx context.Context, kbID, trigger string) (*types.KBSnapshot, error)
+	// ListKBSnapshots lists the snapshots of the tenant, optionally of one knowledge base.
+	ListKBSnapshots(ctx context.Context, kbID string) ([]*types.KBSnapshot, error)
+	// GetKBSnapshot returns a snapshot of the tenant.
+	GetKBSnapshot(ctx context.Context, id string) (*types.KBSnapshot, error)
+	// DeleteKBSnapshot deletes a snapshot and its archive.
+	DeleteKBSnapshot(ctx context.Context, id string) error
+	// RestoreKBSnapshot restores a snapshot into a new knowledge base asynchronously.
+	RestoreKBSnapshot(ctx context.Context, id, name string) (*types.KBCloneProgress, error)
+	// GetKBSnapshotPolicy returns the snapshot schedule of a knowledge base.
+	GetKBSnapshotPolicy(ctx context.Context, kbID string) (*types.KBSnapshotPolicy, error)
+	// UpdateKBSnapshotPolicy replaces the snapshot schedule of a knowledge base.
+	UpdateKBSnapshotPolicy(ctx context.Context, kbID string, policy *types.KBSnapshotPolicy) (*types.KBSnapshotPolicy, error)
 	// ReparseKnowledge deletes existing document content and re-parses the knowledge asynchronously.
 	// When processOverrides is non-nil, it is validated and persisted to the knowledge metadata
 	// before re-parsing, letting callers adjust parse config on reparse; nil keeps stored overrides.
@@ -103,6 +158,10 @@ type KnowledgeService interface {
 		knowledgeID string,
 		processOverrides *types.KnowledgeProcessOverrides,
 	) (*types.Knowledge, error)
+	// RegenerateQuestions replaces the generated questions of the knowledge's
+	// text chunks with the current question generation settings, asynchronously.
+	// Returns the number of chunks queued.
+	RegenerateQuestions(ctx context.Context, knowledgeID string) (int, error)
 	// CancelKnowledgeParse marks an in-progress parse as cancelled by the
 	// user. The knowledge row and any partially written chunks/index are
 	// kept; downstream queued tasks for the same knowledge are best-effort
@@ -168,18 +227,26 @@ type KnowledgeService interface {
 	ProcessDocument(ctx context.Context, t *asynq.Task) error
 	// ProcessFAQImport handles Asynq FAQ import tasks
 	ProcessFAQImport(ctx context.Context, t *asynq.Task) error
+	// ProcessChunkImport handles Asynq pre-chunked data import tasks
+	ProcessChunkImport(ctx context.Context, t *asynq.Task) error
 	// ProcessQuestionGeneration handles Asynq question generation tasks
 	ProcessQuestionGeneration(ctx context.Context, t *asynq.Task) error
 	// ProcessSummaryGeneration handles Asynq summary generation tasks
 	ProcessSummaryGeneration(ctx context.Context, t *asynq.Task) error
 	// ProcessKBClone handles Asynq knowledge base clone tasks
 	ProcessKBClone(ctx context.Context, t *asynq.Task) error
+	// ProcessKBSnapshot handles Asynq knowledge base snapshot tasks
+	ProcessKBSnapshot(ctx context.Context, t *asynq.Task) error
+	// ProcessKBRestore handles Asynq knowledge base snapshot restore tasks
+	ProcessKBRestore(ctx context.Context, t *asynq.Task) error
 	// ProcessKnowledgeMove handles Asynq knowledge move tasks
 	ProcessKnowledgeMove(ctx context.Context, t *asynq.Task) error
 	// ProcessKnowledgeListDelete handles Asynq knowledge list delete tasks
 	ProcessKnowledgeListDelete(ctx context.Context, t *asynq.Task) error
 	// ProcessKnowledgeListReparse handles Asynq knowledge list reparse tasks
 	ProcessKnowledgeListReparse(ctx context.Context, t *asynq.Task) error
+	// ProcessFilePreview handles Asynq file preview generation tasks
+	ProcessFilePreview(ctx context.Context, t *asynq.Task) error
 	// GetKBCloneProgress retrieves the progress of a knowledge base clone task
 	GetKBCloneProgress(ctx context.Context, taskID string) (*types.KBCloneProgress, error)
 	// SaveKBCloneProgress saves the progress of a knowledge base clone task
@@ -190,11 +257,21 @@ type KnowledgeService interface {
 	SaveKnowledgeMoveProgress(ctx context.Context, progress *types.KnowledgeMoveProgress) error
 	// GetFAQImportProgress retrieves the progress of an FAQ import task
 	GetFAQImportProgress(ctx context.Context, taskID string) (*types.FAQImportProgress, error)
+	// GetChunkImportProgress retrieves the progress of a chunk import task
+	GetChunkImportProgress(ctx context.Context, taskID string) (*types.ChunkImportProgress, error)
 	// UpdateLastFAQImportResultDisplayStatus updates the display status of FAQ import result
 	UpdateLastFAQImportResultDisplayStatus(ctx context.Context, kbID string, displayStatus string) error
 	// SearchKnowledge searches knowledge items by keyword across the tenant.
 	// fileTypes: optional list of file extensions to filter by (e.g., ["csv", "xlsx"])
 	SearchKnowledge(ctx context.Context, keyword string, offset, limit int, fileTypes []string) ([]*types.Knowledge, bool, int64, error)
+	// ReencryptKnowledgeFiles rewrites the knowledge base's stored files under
+	// its storage's current server-side encryption key, e.g. after a KMS key
+	// rotation. Files already recorded under that key are skipped.
+	ReencryptKnowledgeFiles(ctx context.Context, kbID string) (*types.FileReencryptResult, error)
+	// MigrateKnowledgeFiles copies the knowledge base's stored files to another
+	// configured storage provider, verifies their checksums and rewrites the
+	// stored paths in one transaction.
+	MigrateKnowledgeFiles(ctx context.Context, kbID string, req *types.FileMigrationRequest) (*types.FileMigrationResult, error)
 	// SearchKnowledgeForScopes searches knowledge within the given (tenant_id, kb_id) scopes (e.g. for shared agent context).
 	SearchKnowledgeForScopes(ctx context.Context, scopes []types.KnowledgeSearchScope, keyword string, offset, limit int, fileTypes []string) ([]*types.Knowledge, bool, int64, error)
 }
@@ -231,11 +308,25 @@ type KnowledgeRepository interface {
 	) (bool, *types.Knowledge, error)
 	// AminusB returns the difference set of A and B.
 	AminusB(ctx context.Context, Atenant uint64, A string, Btenant uint64, B string) ([]string, error)
+	// MoveKnowledgeToKnowledgeBase moves a knowledge entry, its chunks and
+	// tag relations to targetKBID in one transaction, rewriting tags through
+	// tagMapping (source tag ID to target tag ID).
+	MoveKnowledgeToKnowledgeBase(ctx context.Context, tenantID uint64, knowledgeID string,
+		targetKBID string, tagMapping map[string]string) error
+	// ListDuplicateKnowledgeIDs maps the knowledge of sourceKBID whose file
+	// hash already exists in targetKBID to the matching target knowledge.
+	ListDuplicateKnowledgeIDs(ctx context.Context, tenantID uint64, sourceKBID string,
+		targetKBID string) (map[string]string, error)
 	UpdateKnowledgeColumn(ctx context.Context, id string, column string, value interface{}) error
 	// UpdateKnowledgeColumns updates multiple columns of a knowledge row in a single
 	// statement so callers that flip several related fields (e.g. parse_status +
 	// error_message) cannot leave the row in a half-updated state.
 	UpdateKnowledgeColumns(ctx context.Context, id string, values map[string]interface{}) error
+	// RewriteKnowledgeFilePaths points migrated knowledge rows at their new
+	// files and switches the knowledge base to provider in one transaction.
+	// Rows whose file_path no longer equals the copied path are left alone;
+	// their IDs are returned.
+	RewriteKnowledgeFilePaths(ctx context.Context, kbID string, provider string, moves []types.KnowledgeFileMove) ([]string, error)
 	// UpdateActiveDeletingKnowledgeColumns updates an active, non-deleted knowledge row
 	// only when it is still in the transient deleting state.
 	UpdateActiveDeletingKnowledgeColumns(ctx context.Context, id string, values map[string]interface{}) (bool, error)
@@ -265,8 +356,14 @@ type KnowledgeRepository interface {
 	SearchKnowledgeInScopes(ctx context.Context, scopes []types.KnowledgeSearchScope, keyword string, offset, limit int, fileTypes []string) ([]*types.Knowledge, bool, int64, error)
 	// ListIDsByTagIDs returns all knowledge IDs that have any of the specified tag IDs (OR semantics).
 	ListIDsByTagIDs(ctx context.Context, tenantID uint64, kbID string, tagIDs []string) ([]string, error)
+	// ListACLKnowledge returns the knowledge items of the knowledge bases that carry an ACL,
+	// with only their ID, tenant, knowledge base and metadata loaded.
+	ListACLKnowledge(ctx context.Context, kbIDs []string) ([]*types.Knowledge, error)
 	// SetKnowledgeTags replaces all tags for a single knowledge entry (deletes old, inserts new).
 	SetKnowledgeTags(ctx context.Context, knowledgeID string, tagIDs []string) error
+	// SetAutoKnowledgeTags replaces the auto-attached tags of a knowledge entry,
+	// keeping its manually attached ones.
+	SetAutoKnowledgeTags(ctx context.Context, knowledgeID string, tagIDs []string) error
 	// GetKnowledgeTags returns tags for multiple knowledge IDs.
 	GetKnowledgeTags(ctx context.Context, knowledgeIDs []string) (map[string][]*types.KnowledgeTag, error)
 	// DeleteKnowledgeTagRelations deletes all tag relations for a knowledge entry.
@@ -0,0 +1,36 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// KnowledgeGapService records the queries that retrieved nothing from a
+// knowledge base and reports them, clustered by similarity, so content
+// owners know which documents to add
+type KnowledgeGapService interface {
+	// RecordGaps records a gap in each knowledge base a zero-result query
+	// searched; failures are only logged
+	RecordGaps(ctx context.Context, log *types.QueryLog)
+	// GetGapReport clusters the gaps of a knowledge base
+	GetGapReport(
+		ctx context.Context, kbID string, req *types.KnowledgeGapReportRequest,
+	) (*types.KnowledgeGapReport, error)
+	// DismissGaps deletes gaps of a knowledge base and returns how many it deleted
+	DismissGaps(ctx context.Context, kbID string, gapIDs []string) (int64, error)
+}
+
+// KnowledgeGapRepository stores knowledge gaps
+type KnowledgeGapRepository interface {
+	// RecordGaps creates the gaps or adds their counts to the existing
+	// gaps of the same query
+	RecordGaps(ctx context.Context, gaps []*types.KnowledgeGap) error
+	// ListGaps returns up to limit gaps of a knowledge base, most frequent
+	// first, with the number of gaps it has
+	ListGaps(ctx context.Context, tenantID uint64, kbID string, limit int) ([]*types.KnowledgeGap, int64, error)
+	// SaveEmbeddings stores the query embeddings of gaps
+	SaveEmbeddings(ctx context.Context, gaps []*types.KnowledgeGap) error
+	// DeleteGaps deletes gaps of a knowledge base and returns how many it deleted
+	DeleteGaps(ctx context.Context, tenantID uint64, kbID string, ids []string) (int64, error)
+}
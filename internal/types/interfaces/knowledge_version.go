@@ -0,0 +1,22 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// KnowledgeVersionRepository stores the file version history of knowledge
+type KnowledgeVersionRepository interface {
+	// CreateVersion adds a version
+	CreateVersion(ctx context.Context, version *types.KnowledgeVersion) error
+	// ListVersions returns the versions of a knowledge, newest first
+	ListVersions(ctx context.Context, tenantID uint64, knowledgeID string) ([]*types.KnowledgeVersion, error)
+	// GetLatestVersion returns the newest version of a knowledge, or nil when
+	// the knowledge has no recorded version
+	GetLatestVersion(ctx context.Context, tenantID uint64, knowledgeID string) (*types.KnowledgeVersion, error)
+	// UpdateChunkStats records the chunk diff of an indexed version
+	UpdateChunkStats(ctx context.Context, tenantID uint64, id string, added, reused, removed int) error
+	// DeleteByKnowledgeID deletes the versions of a knowledge
+	DeleteByKnowledgeID(ctx context.Context, tenantID uint64, knowledgeID string) error
+}
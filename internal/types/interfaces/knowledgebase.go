@@ -236,4 +236,22 @@ type KnowledgeBaseRepository interface {
 	ListUserKBPinIDs(
 		ctx context.Context, tenantID uint64, userID string,
 	) (map[string]time.Time, error)
+
+	// BeginIndexGeneration reserves the next index generation for a migration
+	// and returns it. Fails with ErrIndexMigrationInProgress while another
+	// generation is pending.
+	BeginIndexGeneration(ctx context.Context, id string) (int64, error)
+
+	// AbortIndexGeneration releases the pending generation without switching
+	// to it; the staged chunks are left for the caller to delete.
+	AbortIndexGeneration(ctx context.Context, id string, generation int64) error
+
+	// SwitchIndexGeneration atomically makes the pending generation active and
+	// retires the older chunks (of chunkTypes) of every knowledge rebuilt in
+	// it. A non-empty embeddingModelID is applied to the knowledge base and
+	// the rebuilt knowledge in the same transaction. Returns the retired
+	// chunk IDs.
+	SwitchIndexGeneration(
+		ctx context.Context, id string, generation int64, chunkTypes []types.ChunkType, embeddingModelID string,
+	) ([]string, error)
 }
@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"time"
 
 	"github.com/Tencent/WeKnora/internal/types"
 )
@@ -13,15 +14,138 @@ type MemoryService interface {
 
 	// RetrieveMemory retrieves relevant memory context based on the current query and user
 	RetrieveMemory(ctx context.Context, userID string, query string) (*types.MemoryContext, error)
+
+	// GetUserProfile returns the stable facts learned about the user
+	GetUserProfile(ctx context.Context, userID string) (*types.UserProfile, error)
+
+	// DeleteProfileFact removes one fact from the user's profile
+	DeleteProfileFact(ctx context.Context, userID string, category string, key string) error
+
+	// ListEpisodes lists a page of the user's episodes, newest first
+	ListEpisodes(ctx context.Context, userID string, page *types.Pagination) (*types.PageResult, error)
+
+	// DeleteEpisode deletes one of the user's episodes
+	DeleteEpisode(ctx context.Context, userID string, episodeID string) error
+
+	// ListEntities lists the entities the user's episodes mention
+	ListEntities(ctx context.Context, userID string) ([]*types.MemoryEntity, error)
+
+	// UpdateEntity corrects the type and description of an entity the
+	// user's episodes mention
+	UpdateEntity(ctx context.Context, userID string, entity *types.MemoryEntity) error
+
+	// ListRelationships lists the relationships between the user's entities
+	ListRelationships(ctx context.Context, userID string) ([]*types.MemoryRelationship, error)
+
+	// ExportMemory returns everything remembered about the user
+	ExportMemory(ctx context.Context, userID string) (*types.MemoryExport, error)
+
+	// GetSessionSummary returns the rolling summary of the user's session,
+	// or nil when nothing has been summarized yet
+	GetSessionSummary(ctx context.Context, userID string, sessionID string) (*types.SessionSummary, error)
+
+	// UpdateSessionSummary folds the oldest messages of the session into its
+	// rolling summary once the unsummarized messages exceed the context budget
+	UpdateSessionSummary(ctx context.Context, userID string, sessionID string) error
+
+	// AddSessionEpisode stores the latest answered turns of the session as
+	// one episode; turns of 0 takes as many as fit
+	AddSessionEpisode(ctx context.Context, userID string, sessionID string, turns int) error
+
+	// IsMemorable asks the chat model whether a turn is worth remembering
+	IsMemorable(ctx context.Context, messages []types.Message) (bool, error)
+
+	// CountSessionTurns returns how many questions have been asked in the session
+	CountSessionTurns(ctx context.Context, sessionID string) (int, error)
 }
 
-// MemoryRepository defines the interface for storing and retrieving memory data
+// MemoryRepository defines the interface for storing and retrieving memory
+// data. The graph is partitioned by tenant: every node belongs to one tenant
+// and every query is scoped to one.
 type MemoryRepository interface {
-	// SaveEpisode saves an episode and its associated entities and relationships to the graph
+	// SaveEpisode saves an episode and its associated entities and
+	// relationships to the graph of the episode's tenant
 	SaveEpisode(ctx context.Context, episode *types.Episode, entities []*types.Entity, relations []*types.Relationship) error
 
-	// FindRelatedEpisodes finds episodes related to the given keywords for a specific user
-	FindRelatedEpisodes(ctx context.Context, userID string, keywords []string, limit int) ([]*types.Episode, error)
+	// SaveProfileFacts upserts the user's profile facts learned from the
+	// episode; a fact with an empty value is removed
+	SaveProfileFacts(ctx context.Context, tenantID uint64, userID string, episodeID string, facts []*types.UserProfileFact) error
+
+	// GetProfileFacts returns the user's profile facts
+	GetProfileFacts(ctx context.Context, tenantID uint64, userID string) ([]*types.UserProfileFact, error)
+
+	// FindRelatedEpisodes finds the user's episodes that mention an entity
+	// named by the keywords, or an entity related to one by a relationship
+	// valid at asOf. Episodes that happened after asOf are left out; a zero
+	// asOf queries the graph as it stands now
+	FindRelatedEpisodes(ctx context.Context, tenantID uint64, userID string, keywords []string, asOf time.Time, limit int) ([]*types.Episode, error)
+
+	// FindSimilarEpisodes finds the user's episodes whose summary, or one of
+	// whose entities, is semantically close to the query vector produced by
+	// the embedding model modelID
+	FindSimilarEpisodes(ctx context.Context, tenantID uint64, userID string, modelID string, vector []float32, limit int) ([]*types.Episode, error)
+
+	// ListEpisodes lists a page of the user's episodes, archived ones
+	// included, newest first, along with the user's total episode count
+	ListEpisodes(ctx context.Context, tenantID uint64, userID string, offset int, limit int) ([]*types.Episode, int64, error)
+
+	// DeleteEpisode deletes one of the user's episodes; it returns
+	// types.ErrMemoryNotFound when the user has no such episode
+	DeleteEpisode(ctx context.Context, tenantID uint64, userID string, episodeID string) error
+
+	// ListUserEntities lists the entities mentioned by the user's episodes
+	ListUserEntities(ctx context.Context, tenantID uint64, userID string) ([]*types.MemoryEntity, error)
+
+	// ListActiveRelationships lists up to limit currently valid
+	// relationships touching the named entities that the user asserted,
+	// or that predate validity tracking
+	ListActiveRelationships(ctx context.Context, tenantID uint64, userID string, names []string, limit int) ([]*types.MemoryRelationship, error)
+
+	// InvalidateRelationships closes the given relationships of the
+	// episode's tenant at the time the contradicting episode happened
+	InvalidateRelationships(ctx context.Context, episode *types.Episode, relationshipIDs []string) error
+
+	// ListUserRelationships lists the relationships between entities
+	// mentioned by the user's episodes, invalidated ones included
+	ListUserRelationships(ctx context.Context, tenantID uint64, userID string) ([]*types.MemoryRelationship, error)
+
+	// UpdateEntity sets the type and description of an entity mentioned by
+	// the user's episodes; it returns types.ErrMemoryNotFound otherwise
+	UpdateEntity(ctx context.Context, tenantID uint64, userID string, entity *types.MemoryEntity) error
+
+	// ListTenantEntities lists up to limit entities mentioned by the tenant's
+	// episodes, most mentioned first
+	ListTenantEntities(ctx context.Context, tenantID uint64, limit int) ([]*types.MemoryEntity, error)
+
+	// MergeEntities folds the tenant's duplicate entities into canonical:
+	// their mentions and relationships are moved over, their names are
+	// recorded as aliases of canonical and the duplicate nodes are deleted
+	MergeEntities(ctx context.Context, tenantID uint64, canonical string, duplicates []string) error
+
+	// RecordEpisodeAccess bumps the access count and last access time of
+	// the retrieved episodes
+	RecordEpisodeAccess(ctx context.Context, tenantID uint64, episodeIDs []string) error
+
+	// ListMemoryUsers lists the users that have active episodes, once per
+	// tenant they have them in
+	ListMemoryUsers(ctx context.Context) ([]types.MemoryUser, error)
+
+	// ListUserEpisodes lists the user's active episodes without their
+	// embeddings, for scoring by the forgetting policy
+	ListUserEpisodes(ctx context.Context, tenantID uint64, userID string) ([]*types.Episode, error)
+
+	// ArchiveEpisodes hides episodes from retrieval while keeping them in the graph
+	ArchiveEpisodes(ctx context.Context, tenantID uint64, episodeIDs []string) error
+
+	// DeleteEpisodes deletes episodes along with the entities only they mention
+	DeleteEpisodes(ctx context.Context, tenantID uint64, episodeIDs []string) error
+
+	// GetSessionSummary returns the rolling summary of a session, or nil
+	// when it has none
+	GetSessionSummary(ctx context.Context, tenantID uint64, sessionID string) (*types.SessionSummary, error)
+
+	// SaveSessionSummary creates or replaces the rolling summary of a session
+	SaveSessionSummary(ctx context.Context, summary *types.SessionSummary) error
 
 	// IsAvailable checks if the memory repository is available
 	IsAvailable(ctx context.Context) bool
@@ -85,6 +85,10 @@ type MessageRepository interface {
 	DeleteMessagesBySessionID(ctx context.Context, sessionID string) error
 	// GetFirstMessageOfUser gets the first message of a user
 	GetFirstMessageOfUser(ctx context.Context, sessionID string) (*types.Message, error)
+	// GetUserMessageByRequestID gets the user message of a request, i.e. the question an answer responds to
+	GetUserMessageByRequestID(ctx context.Context, sessionID string, requestID string) (*types.Message, error)
+	// CountUserMessagesBySession counts the user messages, i.e. the turns, of a session
+	CountUserMessagesBySession(ctx context.Context, sessionID string) (int64, error)
 	// SearchMessagesByKeyword searches messages by keyword (ILIKE) across sessions for a tenant
 	SearchMessagesByKeyword(ctx context.Context, tenantID uint64, keyword string, sessionIDs []string, limit int) ([]*types.MessageWithSession, error)
 	// GetMessagesByKnowledgeIDs retrieves messages by their associated Knowledge IDs
@@ -0,0 +1,70 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// RetentionService manages retention policies and applies them
+type RetentionService interface {
+	// GetTenantPolicy returns the tenant-wide retention policy of the
+	// caller's tenant; a tenant without one reports a disabled default
+	GetTenantPolicy(ctx context.Context) (*types.RetentionPolicy, error)
+	// UpdateTenantPolicy replaces the tenant-wide retention policy
+	UpdateTenantPolicy(ctx context.Context, req *types.RetentionPolicyRequest) (*types.RetentionPolicy, error)
+	// GetKnowledgeBasePolicy returns the retention policy of a knowledge
+	// base; a knowledge base without one reports a disabled default
+	GetKnowledgeBasePolicy(ctx context.Context, kbID string) (*types.RetentionPolicy, error)
+	// UpdateKnowledgeBasePolicy replaces the retention policy of a knowledge base
+	UpdateKnowledgeBasePolicy(ctx context.Context, kbID string, req *types.RetentionPolicyRequest) (*types.RetentionPolicy, error)
+	// PreviewPolicies reports what each retention policy of the caller's
+	// tenant would delete if it ran now, without deleting anything
+	PreviewPolicies(ctx context.Context) ([]*types.RetentionReport, error)
+	// ApplyPolicy runs a retention policy, deleting everything past its
+	// cutoffs. ctx must carry the policy's tenant.
+	ApplyPolicy(ctx context.Context, policy *types.RetentionPolicy) (*types.RetentionReport, error)
+}
+
+// RetentionRepository stores retention policies and finds and purges the
+// data past their cutoffs
+type RetentionRepository interface {
+	// GetPolicy returns the policy of a knowledge base, or the tenant-wide
+	// policy for an empty knowledgeBaseID; nil when none was configured
+	GetPolicy(ctx context.Context, tenantID uint64, knowledgeBaseID string) (*types.RetentionPolicy, error)
+	// SavePolicy creates or replaces a policy
+	SavePolicy(ctx context.Context, policy *types.RetentionPolicy) error
+	// ListPolicies returns the policies of a tenant, the tenant-wide one first
+	ListPolicies(ctx context.Context, tenantID uint64) ([]*types.RetentionPolicy, error)
+	// ListDuePolicies returns up to limit enabled policies due at now
+	ListDuePolicies(ctx context.Context, now time.Time, limit int) ([]*types.RetentionPolicy, error)
+	// ClaimPolicy moves the next run of a policy from prev to next and
+	// reports whether this caller won the claim
+	ClaimPolicy(ctx context.Context, id string, prev, next time.Time) (bool, error)
+	// DeletePolicy deletes a policy
+	DeletePolicy(ctx context.Context, id string) error
+
+	// CountIdleSessions counts the sessions of a tenant not updated since before
+	CountIdleSessions(ctx context.Context, tenantID uint64, before time.Time) (int64, error)
+	// ListIdleSessionIDs returns up to limit sessions of a tenant not
+	// updated since before
+	ListIdleSessionIDs(ctx context.Context, tenantID uint64, before time.Time, limit int) ([]string, error)
+	// PurgeSessions permanently deletes sessions of a tenant with their
+	// messages and returns how many messages went with them
+	PurgeSessions(ctx context.Context, tenantID uint64, ids []string) (int64, error)
+	// CountOldMessages counts the messages of a tenant created before before
+	CountOldMessages(ctx context.Context, tenantID uint64, before time.Time) (int64, error)
+	// PurgeOldMessages permanently deletes up to limit messages of a tenant
+	// created before before and returns how many it deleted
+	PurgeOldMessages(ctx context.Context, tenantID uint64, before time.Time, limit int) (int64, error)
+	// PurgeKnowledgeGaps permanently deletes the knowledge gaps of a tenant
+	// last seen before before
+	PurgeKnowledgeGaps(ctx context.Context, tenantID uint64, before time.Time) error
+	// CountOldKnowledge counts the documents of a knowledge base created
+	// before before, leaving out those already being deleted
+	CountOldKnowledge(ctx context.Context, tenantID uint64, knowledgeBaseID string, before time.Time) (int64, error)
+	// ListOldKnowledgeIDs returns up to limit documents of a knowledge base
+	// created before before, leaving out those already being deleted
+	ListOldKnowledgeIDs(ctx context.Context, tenantID uint64, knowledgeBaseID string, before time.Time, limit int) ([]string, error)
+}
@@ -0,0 +1,72 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+	"github.com/hibiken/asynq"
+)
+
+// RetrievalEvalService manages golden datasets and runs them against
+// knowledge bases to measure retrieval and answer quality
+type RetrievalEvalService interface {
+	// CreateDataset stores a golden dataset of the caller's tenant
+	CreateDataset(ctx context.Context, req *types.EvalDatasetRequest) (*types.EvalDataset, error)
+	// ListDatasets returns the golden datasets of the caller's tenant
+	ListDatasets(ctx context.Context) ([]*types.EvalDataset, error)
+	// GetDataset returns a golden dataset with its items
+	GetDataset(ctx context.Context, id string) (*types.EvalDataset, error)
+	// DeleteDataset deletes a golden dataset with its runs
+	DeleteDataset(ctx context.Context, id string) error
+
+	// CreateRun validates a run and enqueues it
+	CreateRun(ctx context.Context, req *types.EvalRunRequest) (*types.EvalRun, error)
+	// ListRuns returns the runs of the caller's tenant, of one dataset when
+	// datasetID is set, newest first
+	ListRuns(ctx context.Context, datasetID string) ([]*types.EvalRun, error)
+	// GetRun returns a run
+	GetRun(ctx context.Context, id string) (*types.EvalRun, error)
+	// ListRunResults returns the per-question results of a run
+	ListRunResults(ctx context.Context, id string) ([]*types.EvalRunResult, error)
+	// DeleteRun deletes a run with its results
+	DeleteRun(ctx context.Context, id string) error
+	// CompareRuns compares completed runs against the first of them
+	CompareRuns(ctx context.Context, ids []string) (*types.EvalRunComparison, error)
+
+	// ProcessEvalRun handles the evaluation run task
+	ProcessEvalRun(ctx context.Context, t *asynq.Task) error
+}
+
+// RetrievalEvalRepository stores golden datasets, evaluation runs and
+// their results
+type RetrievalEvalRepository interface {
+	// CreateDataset creates a dataset with its items
+	CreateDataset(ctx context.Context, dataset *types.EvalDataset, items []*types.EvalDatasetItem) error
+	// GetDataset returns a dataset of a tenant, or nil
+	GetDataset(ctx context.Context, tenantID uint64, id string) (*types.EvalDataset, error)
+	// ListDatasets returns the datasets of a tenant, newest first
+	ListDatasets(ctx context.Context, tenantID uint64) ([]*types.EvalDataset, error)
+	// ListDatasetItems returns the items of a dataset in upload order
+	ListDatasetItems(ctx context.Context, datasetID string) ([]*types.EvalDatasetItem, error)
+	// DeleteDataset deletes a dataset of a tenant with its items, runs and results
+	DeleteDataset(ctx context.Context, tenantID uint64, id string) error
+
+	// CreateRun creates a run
+	CreateRun(ctx context.Context, run *types.EvalRun) error
+	// GetRun returns a run of a tenant, or nil
+	GetRun(ctx context.Context, tenantID uint64, id string) (*types.EvalRun, error)
+	// ListRuns returns the runs of a tenant, of one dataset when datasetID
+	// is set, newest first
+	ListRuns(ctx context.Context, tenantID uint64, datasetID string) ([]*types.EvalRun, error)
+	// UpdateRun saves the state, progress and metrics of a run
+	UpdateRun(ctx context.Context, run *types.EvalRun) error
+	// DeleteRun deletes a run of a tenant with its results
+	DeleteRun(ctx context.Context, tenantID uint64, id string) error
+
+	// SaveRunResult stores the result of one question
+	SaveRunResult(ctx context.Context, result *types.EvalRunResult) error
+	// DeleteRunResults deletes the results of a run, before it is retried
+	DeleteRunResults(ctx context.Context, runID string) error
+	// ListRunResults returns the results of a run in dataset order
+	ListRunResults(ctx context.Context, runID string) ([]*types.EvalRunResult, error)
+}
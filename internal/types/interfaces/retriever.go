@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/Tencent/WeKnora/internal/models/embedding"
 	"github.com/Tencent/WeKnora/internal/types"
@@ -52,6 +53,13 @@ type RetrieveEngineRepository interface {
 	// DeleteByKnowledgeIDList deletes the index info by knowledge id list
 	DeleteByKnowledgeIDList(ctx context.Context, knowledgeIDList []string, dimension int, knowledgeType string) error
 
+	// DeleteByKnowledgeBaseIDList deletes every index of the knowledge bases;
+	// engines with per-dimension collections drop the ones left empty
+	DeleteByKnowledgeBaseIDList(ctx context.Context, knowledgeBaseIDList []string, dimension int, knowledgeType string) error
+
+	// DeleteByTagIDList deletes the index info by tag id list
+	DeleteByTagIDList(ctx context.Context, tagIDList []string, dimension int, knowledgeType string) error
+
 	// BatchUpdateChunkEnabledStatus updates the enabled status of chunks in batch
 	// chunkStatusMap: map of chunk ID to enabled status (true = enabled, false = disabled)
 	BatchUpdateChunkEnabledStatus(ctx context.Context, chunkStatusMap map[string]bool) error
@@ -60,10 +68,87 @@ type RetrieveEngineRepository interface {
 	// chunkTagMap: map of chunk ID to tag ID (empty string means no tag)
 	BatchUpdateChunkTagID(ctx context.Context, chunkTagMap map[string]string) error
 
+	// Capabilities describes the operators, limits and features the engine
+	// supports. It must not block on the backend.
+	Capabilities() types.EngineCapabilities
+
 	// RetrieveEngine retrieves the engine
 	RetrieveEngine
 }
 
+// CollectionReindexer is implemented by engines whose collection schema is
+// fixed at create time (e.g. the Milvus content analyzer) and that can rebuild
+// existing collections from their own stored rows. Callers type-assert a
+// RetrieveEngineService against it; engines without schema-level settings do
+// not implement it.
+type CollectionReindexer interface {
+	// ReindexCollections rebuilds every collection owned by the engine with
+	// the current index configuration.
+	ReindexCollections(ctx context.Context) (*types.ReindexReport, error)
+}
+
+// ModelSpaceMigrator is implemented by engines that keep one vector space per
+// embedding model and still hold rows in the legacy per-dimension layout.
+// kbModels maps knowledge base ID to its embedding model ID; only rows of
+// those knowledge bases are moved.
+type ModelSpaceMigrator interface {
+	MigrateModelSpaces(ctx context.Context, kbModels map[string]string) (*types.ModelSpaceMigrationReport, error)
+}
+
+// IndexDeduplicator is implemented by engines that can repair rows written
+// before Save derived primary keys from types.IndexRowID: each row is moved
+// to its deterministic ID, which collapses retry duplicates into one row.
+type IndexDeduplicator interface {
+	DeduplicateIndices(ctx context.Context) (*types.DedupReport, error)
+}
+
+// FilterValidator is implemented by engines that accept structured metadata
+// filter conditions. ValidateFilter compiles the condition without running a
+// query so schema mismatches surface before execution.
+type FilterValidator interface {
+	ValidateFilter(ctx context.Context, filter json.RawMessage) (*types.FilterValidation, error)
+}
+
+// EmbeddedIndexer is implemented by engines that can save rows whose vectors
+// were computed outside WeKnora (bulk chunk import). embeddings maps
+// SourceID to its vector and must cover every row when the engine serves
+// vector retrieval; modelID is stamped on the rows like BatchIndex does.
+type EmbeddedIndexer interface {
+	BatchIndexEmbedded(ctx context.Context, modelID string, indexInfoList []*types.IndexInfo,
+		embeddings map[string][]float32, retrieverTypes []types.RetrieverType) error
+}
+
+// IndexExporter is implemented by engines that can read their rows back
+// with the stored vectors (knowledge base snapshots). fn receives the rows of
+// knowledgeBaseID in batches of at most batchSize; an error from fn stops the
+// export and is returned.
+type IndexExporter interface {
+	ExportIndices(ctx context.Context, knowledgeBaseID string, batchSize int,
+		fn func([]*types.ExportedIndex) error) error
+}
+
+// IndexMover is implemented by engines that can reassign index rows to
+// another knowledge base of the same store in place (knowledge moves and
+// merges). The rows of knowledgeIDs are moved to targetKnowledgeBaseID;
+// chunkTagMap, keyed by chunk ID, rewrites the tag of the listed chunks.
+type IndexMover interface {
+	MoveIndices(ctx context.Context, knowledgeIDs []string, targetKnowledgeBaseID string,
+		chunkTagMap map[string]string) error
+}
+
+// CapabilityReporter is implemented by retrieve engine services that can
+// describe their engine; see RetrieveEngineRepository.Capabilities.
+type CapabilityReporter interface {
+	Capabilities() types.EngineCapabilities
+}
+
+// LoadStateReporter is implemented by engines that load collections into
+// memory before they can be searched; LoadStates reports how far each
+// collection is loaded.
+type LoadStateReporter interface {
+	LoadStates(ctx context.Context) (*types.LoadStateReport, error)
+}
+
 // RetrieveEngineRegistry defines the retrieve engine registry interface
 type RetrieveEngineRegistry interface {
 	// Register registers the retrieve engine service
@@ -128,6 +213,13 @@ type RetrieveEngineService interface {
 	// DeleteByKnowledgeIDList deletes the index info by knowledge id list
 	DeleteByKnowledgeIDList(ctx context.Context, knowledgeIDList []string, dimension int, knowledgeType string) error
 
+	// DeleteByKnowledgeBaseIDList deletes every index of the knowledge bases;
+	// engines with per-dimension collections drop the ones left empty
+	DeleteByKnowledgeBaseIDList(ctx context.Context, knowledgeBaseIDList []string, dimension int, knowledgeType string) error
+
+	// DeleteByTagIDList deletes the index info by tag id list
+	DeleteByTagIDList(ctx context.Context, tagIDList []string, dimension int, knowledgeType string) error
+
 	// BatchUpdateChunkEnabledStatus updates the enabled status of chunks in batch
 	// chunkStatusMap: map of chunk ID to enabled status (true = enabled, false = disabled)
 	BatchUpdateChunkEnabledStatus(ctx context.Context, chunkStatusMap map[string]bool) error
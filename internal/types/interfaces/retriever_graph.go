@@ -14,4 +14,7 @@ type RetrieveGraphRepository interface {
 	DelGraph(ctx context.Context, namespace []types.NameSpace) error
 	// SearchNode searches for nodes in the repository
 	SearchNode(ctx context.Context, namespace types.NameSpace, nodes []string) (*types.GraphData, error)
+	// GetGraph returns every node and relation of the namespace, merging
+	// nodes of the same name extracted from different knowledge
+	GetGraph(ctx context.Context, namespace types.NameSpace) (*types.GraphData, error)
 }
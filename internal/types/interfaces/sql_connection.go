@@ -0,0 +1,48 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// SQLConnectionRepository defines the repository interface for SQL connection CRUD
+type SQLConnectionRepository interface {
+	// Create creates a new SQL connection
+	Create(ctx context.Context, conn *types.SQLConnection) error
+	// GetByID retrieves a SQL connection by ID within a tenant scope, or nil if none
+	GetByID(ctx context.Context, tenantID uint64, id string) (*types.SQLConnection, error)
+	// List lists all SQL connections for a tenant
+	List(ctx context.Context, tenantID uint64) ([]*types.SQLConnection, error)
+	// Update updates a SQL connection
+	Update(ctx context.Context, conn *types.SQLConnection) error
+	// Delete deletes a SQL connection (soft delete)
+	Delete(ctx context.Context, tenantID uint64, id string) error
+}
+
+// SQLConnectionService manages the tenant databases knowledge QA may answer
+// analytical questions from and runs read-only queries against them.
+// Tenant isolation is enforced by the handler layer (getOwned pattern).
+type SQLConnectionService interface {
+	// CreateConnection validates and creates a new SQL connection.
+	// conn.TenantID must be set by the caller (handler).
+	CreateConnection(ctx context.Context, conn *types.SQLConnection) error
+	// GetConnection retrieves a connection by tenant + id, or nil if none
+	GetConnection(ctx context.Context, tenantID uint64, id string) (*types.SQLConnection, error)
+	// ListConnections lists the connections of a tenant
+	ListConnections(ctx context.Context, tenantID uint64) ([]*types.SQLConnection, error)
+	// UpdateConnection validates and updates an existing connection.
+	// An empty password keeps the stored one.
+	UpdateConnection(ctx context.Context, conn *types.SQLConnection) error
+	// DeleteConnection deletes a connection by tenant + id
+	DeleteConnection(ctx context.Context, tenantID uint64, id string) error
+	// TestConnection opens the database and pings it
+	TestConnection(ctx context.Context, conn *types.SQLConnection) error
+	// DescribeSchema returns a compact description of the tables and columns
+	// the connection may query, for prompting SQL generation
+	DescribeSchema(ctx context.Context, conn *types.SQLConnection) (string, error)
+	// ExecuteQuery validates sql as a single read-only SELECT over the
+	// allowed tables and runs it in a read-only transaction within the
+	// connection's row and time limits
+	ExecuteQuery(ctx context.Context, conn *types.SQLConnection, sql string) (*types.SQLQueryResult, error)
+}
@@ -11,10 +11,25 @@ import (
 type KnowledgeTagService interface {
 	// ListTags lists all tags under a knowledge base with associated statistics.
 	ListTags(ctx context.Context, kbID string, page *types.Pagination, keyword string) (*types.PageResult, error)
-	// CreateTag creates a new tag under a knowledge base.
-	CreateTag(ctx context.Context, kbID string, name string, color string, sortOrder int) (*types.KnowledgeTag, error)
-	// UpdateTag updates tag basic information.
-	UpdateTag(ctx context.Context, id string, name *string, color *string, sortOrder *int) (*types.KnowledgeTag, error)
+	// CreateTag creates a new tag under a knowledge base, below parentID when it is not empty.
+	CreateTag(
+		ctx context.Context,
+		kbID string,
+		name string,
+		color string,
+		sortOrder int,
+		parentID string,
+	) (*types.KnowledgeTag, error)
+	// UpdateTag updates tag basic information; a non-nil parentID moves the tag,
+	// an empty one to the top level.
+	UpdateTag(
+		ctx context.Context,
+		id string,
+		name *string,
+		color *string,
+		sortOrder *int,
+		parentID *string,
+	) (*types.KnowledgeTag, error)
 	// DeleteTag deletes a tag.
 	// When contentOnly=true, only deletes the content under the tag but keeps the tag itself.
 	// excludeIDs: IDs of chunks to exclude from deletion (only valid when deleting chunks)
@@ -44,6 +59,10 @@ type KnowledgeTagRepository interface {
 		page *types.Pagination,
 		keyword string,
 	) ([]*types.KnowledgeTag, int64, error)
+	// ListAllByKB lists every tag of a knowledge base, for resolving the tag hierarchy.
+	ListAllByKB(ctx context.Context, tenantID uint64, kbID string) ([]*types.KnowledgeTag, error)
+	// ReparentChildren moves the child tags of parentID under newParentID.
+	ReparentChildren(ctx context.Context, tenantID uint64, kbID string, parentID string, newParentID string) error
 	Delete(ctx context.Context, tenantID uint64, id string) error
 	// CountReferences returns number of knowledges and chunks that reference the tag.
 	CountReferences(
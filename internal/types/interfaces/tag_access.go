@@ -0,0 +1,40 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// TagScopeService resolves tag filters and tag access rules on retrieval.
+type TagScopeService interface {
+	// ScopeSearchTargets expands the tag filters of the targets to their
+	// descendant tags and narrows the targets to the tag subtrees the caller
+	// in ctx may access. Targets left with nothing to search are dropped.
+	ScopeSearchTargets(ctx context.Context, targets types.SearchTargets) (types.SearchTargets, error)
+	// ListAccessRules lists the tag access rules of a knowledge base.
+	ListAccessRules(ctx context.Context, kbID string) ([]*types.TagAccessRule, error)
+	// SaveAccessRule creates or replaces the rule of a subject on a knowledge base.
+	SaveAccessRule(ctx context.Context, kbID string, req *types.TagAccessRuleRequest) (*types.TagAccessRule, error)
+	// DeleteAccessRule deletes a tag access rule of a knowledge base.
+	DeleteAccessRule(ctx context.Context, kbID string, ruleID string) error
+}
+
+// TagAccessRuleRepository persists tag access rules.
+type TagAccessRuleRepository interface {
+	// ListByKB lists the rules of a knowledge base.
+	ListByKB(ctx context.Context, tenantID uint64, kbID string) ([]*types.TagAccessRule, error)
+	// ListForSubjects lists the rules of any of the subjects on any of the
+	// knowledge bases.
+	ListForSubjects(
+		ctx context.Context,
+		tenantID uint64,
+		kbIDs []string,
+		subjects []types.TagAccessSubject,
+	) ([]*types.TagAccessRule, error)
+	// Save creates the rule, or replaces the tags of the subject's existing
+	// rule on the knowledge base.
+	Save(ctx context.Context, rule *types.TagAccessRule) error
+	// Delete deletes a rule of a knowledge base.
+	Delete(ctx context.Context, tenantID uint64, kbID string, id string) error
+}
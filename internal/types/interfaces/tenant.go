@@ -24,6 +24,8 @@ type TenantService interface {
 	DeleteTenant(ctx context.Context, id uint64) error
 	// UpdateAPIKey updates the API key
 	UpdateAPIKey(ctx context.Context, id uint64) (string, error)
+	// UpdateAPIKeyRole sets the tenant role granted to the API key
+	UpdateAPIKeyRole(ctx context.Context, id uint64, role types.TenantRole) (*types.Tenant, error)
 	// ExtractTenantIDFromAPIKey extracts the tenant ID from the API key
 	ExtractTenantIDFromAPIKey(apiKey string) (uint64, error)
 	// ListAllTenants lists all tenants (for users with cross-tenant access permission)
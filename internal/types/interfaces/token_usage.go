@@ -0,0 +1,34 @@
+package interfaces
+
+import (
+	"context"
+
+	"github.com/Tencent/WeKnora/internal/types"
+)
+
+// TokenUsageRepository stores the daily token usage aggregates
+type TokenUsageRepository interface {
+	// AddUsage adds the counts of usage to the aggregate of its tenant, day,
+	// model and session, creating the aggregate if needed
+	AddUsage(ctx context.Context, usage *types.TokenUsageDaily) error
+	// Summarize returns the usage matching query, one summary per group of
+	// query.GroupBy
+	Summarize(ctx context.Context, query *types.TokenUsageQuery) ([]types.TokenUsageSummary, error)
+	// TotalTokens returns the tokens a tenant used from startDay to endDay inclusive
+	TotalTokens(ctx context.Context, tenantID uint64, startDay, endDay string) (int64, error)
+}
+
+// TokenUsageService accounts the token usage of model calls per tenant,
+// reports it and enforces tenant token budgets. It is registered as the
+// models' usage.Recorder.
+type TokenUsageService interface {
+	// CheckBudget returns an error wrapping types.ErrTokenBudgetExceeded when
+	// the tenant of ctx has used up its daily or monthly token budget
+	CheckBudget(ctx context.Context) error
+	// RecordUsage adds a model call to the usage of the tenant and session of ctx
+	RecordUsage(ctx context.Context, event *types.TokenUsageEvent)
+	// GetReport returns the usage of a tenant over a date range
+	GetReport(ctx context.Context, query *types.TokenUsageQuery) (*types.TokenUsageReport, error)
+	// GetBudgetStatus returns the tenant's budget and the tokens it used today and this month
+	GetBudgetStatus(ctx context.Context, tenant *types.Tenant) (*types.TokenBudgetStatus, error)
+}
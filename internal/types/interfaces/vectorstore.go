@@ -2,6 +2,7 @@ package interfaces
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/Tencent/WeKnora/internal/types"
 )
@@ -47,6 +48,28 @@ type VectorStoreService interface {
 	TestRawConnection(ctx context.Context, engineType types.RetrieverEngineType, config types.ConnectionConfig) (string, error)
 	// SaveDetectedVersion updates the connection_config.version for a stored vector store.
 	SaveDetectedVersion(ctx context.Context, store *types.VectorStore, version string) error
+	// ReindexStore rebuilds the store's collections with its current
+	// index_config (e.g. a changed Milvus analyzer). Returns a validation
+	// error when the engine has no create-time schema settings to rebuild.
+	ReindexStore(ctx context.Context, store *types.VectorStore) (*types.ReindexReport, error)
+	// MigrateStoreModelSpaces moves the calling tenant's rows from legacy
+	// per-dimension collections into per-embedding-model collections.
+	// Works for env stores too since only the tenant's own rows are touched.
+	MigrateStoreModelSpaces(ctx context.Context, tenantID uint64, store *types.VectorStore) (*types.ModelSpaceMigrationReport, error)
+	// DedupStore removes duplicate index rows left by retried writes.
+	// Returns a validation error when the engine has no repair routine.
+	DedupStore(ctx context.Context, store *types.VectorStore) (*types.DedupReport, error)
+	// ValidateFilter compiles a metadata filter condition against the
+	// store's schema without executing it. Read-only, so env stores work.
+	ValidateFilter(ctx context.Context, store *types.VectorStore, filter json.RawMessage) (*types.FilterValidation, error)
+	// GetCapabilities reports the features of the engine serving store
+	// (retriever types, filter operators, limits). Read-only, so env
+	// stores work.
+	GetCapabilities(ctx context.Context, store *types.VectorStore) (*types.EngineCapabilities, error)
+	// GetLoadState reports how far the store's collections are loaded into
+	// memory. Returns a validation error when the engine does not load
+	// collections. Read-only, so env stores work.
+	GetLoadState(ctx context.Context, store *types.VectorStore) (*types.LoadStateReport, error)
 
 	// ResolveStoreView returns the API-safe display projection of a single
 	// store ID, scoped to the given tenant. Tries DB stores first, then the
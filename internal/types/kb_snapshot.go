@@ -0,0 +1,232 @@
+package types
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Knowledge base snapshot statuses.
+const (
+	KBSnapshotStatusPending   = "pending"
+	KBSnapshotStatusRunning   = "running"
+	KBSnapshotStatusCompleted = "completed"
+	KBSnapshotStatusFailed    = "failed"
+)
+
+// Knowledge base snapshot triggers.
+const (
+	KBSnapshotTriggerManual    = "manual"
+	KBSnapshotTriggerScheduled = "scheduled"
+)
+
+// KBSnapshotFormatVersion is written into every snapshot manifest. Restore
+// refuses archives with a newer version.
+const KBSnapshotFormatVersion = 1
+
+// KBSnapshot is a point-in-time copy of a knowledge base: its configuration,
+// tags, knowledge, chunks and (when the retrieve engine can export them)
+// index rows with their vectors, stored in object storage as gzip-compressed
+// JSONL parts. Snapshots outlive the knowledge base so a deleted knowledge
+// base can be restored.
+type KBSnapshot struct {
+	ID                string `json:"id"                  gorm:"type:varchar(36);primaryKey"`
+	TenantID          uint64 `json:"tenant_id"           gorm:"index"`
+	KnowledgeBaseID   string `json:"knowledge_base_id"   gorm:"type:varchar(36);index"`
+	KnowledgeBaseName string `json:"knowledge_base_name"`
+	Trigger           string `json:"trigger"             gorm:"type:varchar(16)"`
+	Status            string `json:"status"              gorm:"type:varchar(16)"`
+	// Parts are the object storage paths of the archive parts, in order
+	Parts StringArray `json:"-"                   gorm:"type:json"`
+	// Size is the total compressed size of the parts in bytes
+	Size           int64 `json:"size"`
+	KnowledgeCount int   `json:"knowledge_count"`
+	ChunkCount     int   `json:"chunk_count"`
+	VectorCount    int   `json:"vector_count"`
+	// VectorsIncluded is false when the retrieve engine cannot export its
+	// rows; restoring such a snapshot re-embeds the chunks
+	VectorsIncluded  bool       `json:"vectors_included"`
+	EmbeddingModelID string     `json:"embedding_model_id"`
+	ErrorMessage     string     `json:"error_message"`
+	CreatedAt        time.Time  `json:"created_at"`
+	CompletedAt      *time.Time `json:"completed_at"`
+}
+
+// TableName returns the table name of KBSnapshot
+func (KBSnapshot) TableName() string {
+	return "kb_snapshots"
+}
+
+// Snapshot policy limits.
+const (
+	DefaultKBSnapshotIntervalHours = 24
+	MaxKBSnapshotIntervalHours     = 24 * 30
+	DefaultKBSnapshotRetain        = 7
+	MaxKBSnapshotRetain            = 100
+)
+
+// KBSnapshotPolicy schedules snapshots of a knowledge base every
+// IntervalHours and keeps the newest Retain scheduled snapshots; manual
+// snapshots are never pruned.
+type KBSnapshotPolicy struct {
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64 `json:"tenant_id"         gorm:"index"`
+	Enabled         bool   `json:"enabled"`
+	IntervalHours   int    `json:"interval_hours"`
+	Retain          int    `json:"retain"`
+	// NextRunAt is when the next scheduled snapshot is due; nil while the
+	// policy is disabled
+	NextRunAt *time.Time `json:"next_run_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name of KBSnapshotPolicy
+func (KBSnapshotPolicy) TableName() string {
+	return "kb_snapshot_policies"
+}
+
+// Normalize fills in defaults and clamps the interval and retention.
+func (p *KBSnapshotPolicy) Normalize() {
+	if p.IntervalHours <= 0 {
+		p.IntervalHours = DefaultKBSnapshotIntervalHours
+	}
+	if p.IntervalHours > MaxKBSnapshotIntervalHours {
+		p.IntervalHours = MaxKBSnapshotIntervalHours
+	}
+	if p.Retain <= 0 {
+		p.Retain = DefaultKBSnapshotRetain
+	}
+	if p.Retain > MaxKBSnapshotRetain {
+		p.Retain = MaxKBSnapshotRetain
+	}
+}
+
+// Interval returns the gap between scheduled snapshots.
+func (p *KBSnapshotPolicy) Interval() time.Duration {
+	return time.Duration(p.IntervalHours) * time.Hour
+}
+
+// Snapshot archive record kinds, in the order they appear in an archive.
+const (
+	KBSnapshotKindManifest      = "manifest"
+	KBSnapshotKindKnowledgeBase = "knowledge_base"
+	KBSnapshotKindTag           = "tag"
+	KBSnapshotKindKnowledge     = "knowledge"
+	KBSnapshotKindChunk         = "chunk"
+	KBSnapshotKindVector        = "vector"
+)
+
+// KBSnapshotManifest opens every snapshot archive.
+type KBSnapshotManifest struct {
+	Version          int       `json:"version"`
+	SnapshotID       string    `json:"snapshot_id"`
+	KnowledgeBaseID  string    `json:"knowledge_base_id"`
+	EmbeddingModelID string    `json:"embedding_model_id"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// KBSnapshotRecord is one JSONL line of a snapshot archive. Exactly the
+// field matching Kind is set.
+type KBSnapshotRecord struct {
+	Kind          string              `json:"kind"`
+	Manifest      *KBSnapshotManifest `json:"manifest,omitempty"`
+	KnowledgeBase *KnowledgeBase      `json:"knowledge_base,omitempty"`
+	Tag           *KnowledgeTag       `json:"tag,omitempty"`
+	Knowledge     *Knowledge          `json:"knowledge,omitempty"`
+	// KnowledgeTagIDs are the tags attached to Knowledge
+	KnowledgeTagIDs []string       `json:"knowledge_tag_ids,omitempty"`
+	Chunk           *Chunk         `json:"chunk,omitempty"`
+	Vector          *ExportedIndex `json:"vector,omitempty"`
+}
+
+// KBSnapshotPayload is the kb:snapshot task payload.
+type KBSnapshotPayload struct {
+	TracingContext
+	TenantID   uint64 `json:"tenant_id"`
+	SnapshotID string `json:"snapshot_id"`
+}
+
+// KBRestorePayload is the kb:restore task payload. The target knowledge
+// base is created before the task is enqueued; progress is reported as a
+// KBCloneProgress under TaskID.
+type KBRestorePayload struct {
+	TracingContext
+	TenantID   uint64 `json:"tenant_id"`
+	TaskID     string `json:"task_id"`
+	SnapshotID string `json:"snapshot_id"`
+	TargetID   string `json:"target_id"`
+}
+
+// KBSnapshotIDMap assigns new IDs to the rows of a restored snapshot. IDs
+// are allocated on first use, so a chunk's links to chunks that appear later
+// in the archive resolve to the same new IDs as the chunks themselves.
+type KBSnapshotIDMap struct {
+	knowledge map[string]string
+	chunks    map[string]string
+	tags      map[string]string
+}
+
+// NewKBSnapshotIDMap returns an empty ID map.
+func NewKBSnapshotIDMap() *KBSnapshotIDMap {
+	return &KBSnapshotIDMap{
+		knowledge: make(map[string]string),
+		chunks:    make(map[string]string),
+		tags:      make(map[string]string),
+	}
+}
+
+func mapSnapshotID(m map[string]string, old string) string {
+	if old == "" {
+		return ""
+	}
+	if id, ok := m[old]; ok {
+		return id
+	}
+	id := uuid.New().String()
+	m[old] = id
+	return id
+}
+
+// Knowledge returns the new ID of a knowledge.
+func (m *KBSnapshotIDMap) Knowledge(old string) string { return mapSnapshotID(m.knowledge, old) }
+
+// Chunk returns the new ID of a chunk.
+func (m *KBSnapshotIDMap) Chunk(old string) string { return mapSnapshotID(m.chunks, old) }
+
+// Tag returns the new ID of a tag.
+func (m *KBSnapshotIDMap) Tag(old string) string { return mapSnapshotID(m.tags, old) }
+
+// HasKnowledge reports whether a knowledge of the archive was restored.
+func (m *KBSnapshotIDMap) HasKnowledge(old string) bool {
+	_, ok := m.knowledge[old]
+	return ok
+}
+
+// HasChunk reports whether a chunk of the archive was restored.
+func (m *KBSnapshotIDMap) HasChunk(old string) bool {
+	_, ok := m.chunks[old]
+	return ok
+}
+
+// HasTag reports whether a tag of the archive was restored.
+func (m *KBSnapshotIDMap) HasTag(old string) bool {
+	_, ok := m.tags[old]
+	return ok
+}
+
+// SourceID rewrites the source ID of an index row. Source IDs are the chunk
+// ID itself or the chunk ID with a suffix (generated questions, FAQ similar
+// questions, table summaries); the suffix is kept. Any other source ID gets
+// a fresh UUID.
+func (m *KBSnapshotIDMap) SourceID(oldSourceID, oldChunkID string) string {
+	newChunkID := m.Chunk(oldChunkID)
+	if oldSourceID == oldChunkID {
+		return newChunkID
+	}
+	if suffix, ok := strings.CutPrefix(oldSourceID, oldChunkID+"-"); ok && oldChunkID != "" {
+		return newChunkID + "-" + suffix
+	}
+	return uuid.New().String()
+}
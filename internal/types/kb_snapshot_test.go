@@ -0,0 +1,49 @@
+package types
+
+import "testing"
+
+func TestKBSnapshotPolicyNormalize(t *testing.T) {
+	p := &KBSnapshotPolicy{}
+	p.Normalize()
+	if p.IntervalHours != DefaultKBSnapshotIntervalHours || p.Retain != DefaultKBSnapshotRetain {
+		t.Errorf("defaults = %d/%d", p.IntervalHours, p.Retain)
+	}
+	p = &KBSnapshotPolicy{IntervalHours: 10000, Retain: 1000}
+	p.Normalize()
+	if p.IntervalHours != MaxKBSnapshotIntervalHours || p.Retain != MaxKBSnapshotRetain {
+		t.Errorf("clamped = %d/%d", p.IntervalHours, p.Retain)
+	}
+}
+
+func TestKBSnapshotIDMap(t *testing.T) {
+	m := NewKBSnapshotIDMap()
+	next := m.Chunk("c2")
+	if m.Chunk("c2") != next || next == "c2" {
+		t.Fatalf("chunk IDs must be allocated once")
+	}
+	if m.Chunk("") != "" {
+		t.Errorf("empty IDs must stay empty")
+	}
+	if m.HasChunk("c1") {
+		t.Errorf("c1 not mapped yet")
+	}
+
+	tests := []struct {
+		source string
+		want   func(newChunk, got string) bool
+	}{
+		{"c1", func(c, got string) bool { return got == c }},
+		{"c1-q-3", func(c, got string) bool { return got == c+"-q-3" }},
+		{"c1-table-summary", func(c, got string) bool { return got == c+"-table-summary" }},
+		{"c10", func(c, got string) bool { return got != c && got != "c10" }},
+	}
+	for _, tt := range tests {
+		got := m.SourceID(tt.source, "c1")
+		if !tt.want(m.Chunk("c1"), got) {
+			t.Errorf("SourceID(%q) = %q", tt.source, got)
+		}
+	}
+	if !m.HasChunk("c1") {
+		t.Errorf("SourceID must map the chunk")
+	}
+}
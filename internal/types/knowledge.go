@@ -14,6 +14,13 @@ const (
 	KnowledgeTypeManual = "manual"
 	// KnowledgeTypeFAQ represents the FAQ knowledge type
 	KnowledgeTypeFAQ = "faq"
+	// KnowledgeTypeGlossary represents the generated glossary container of a knowledge base
+	KnowledgeTypeGlossary = "glossary"
+	// KnowledgeTypeGraphCommunity represents the generated graph community reports of a knowledge base
+	KnowledgeTypeGraphCommunity = "graph_community"
+	// KnowledgeTypeImported represents knowledge whose chunks (and optionally
+	// embeddings) were bulk-imported; it has no source file to reparse
+	KnowledgeTypeImported = "imported"
 )
 
 // Channel constants identify through which channel a knowledge entry was ingested.
@@ -31,6 +38,10 @@ const (
 	ChannelNotion           = "notion"            // Notion
 	ChannelYuque            = "yuque"             // Yuque (语雀)
 	ChannelRSS              = "rss"               // RSS / Atom feed
+	ChannelIMAP             = "imap"              // Email (IMAP)
+	ChannelConfluence       = "confluence"        // Confluence
+	ChannelGit              = "git"               // Git repository
+	ChannelBucket           = "bucket"            // Object storage bucket (S3 / TOS / COS)
 )
 
 // Knowledge parse status constants
@@ -62,6 +73,10 @@ const (
 	// queued downstream tasks, but the knowledge row and any already-written
 	// chunks/index are kept so the user can re-trigger parsing via reparse.
 	ParseStatusCancelled = "cancelled"
+	// ParseStatusQuarantined indicates the upload failed the malware scan
+	// and was stored for review instead of being parsed. Quarantined
+	// knowledge cannot be reparsed; it can only be deleted.
+	ParseStatusQuarantined = "quarantined"
 )
 
 // Summary status constants for async summary generation
@@ -152,6 +167,12 @@ type Knowledge struct {
 	FilePath string `json:"file_path"`
 	// Storage size of the knowledge
 	StorageSize int64 `json:"storage_size"`
+	// Key the stored file was encrypted with server-side, empty when it is not
+	// encrypted; see StorageEncryptionConfig.KeyID
+	EncryptionKeyID string `json:"encryption_key_id"  gorm:"type:varchar(512);not null;default:''"`
+	// Path of the thumbnail generated for image and PDF files, stored next
+	// to the file; empty until one has been generated
+	PreviewPath string `json:"preview_path"       gorm:"type:text;not null;default:''"`
 	// Metadata of the knowledge
 	Metadata JSON `json:"metadata"           gorm:"type:json"`
 	// Last FAQ import result (for FAQ type knowledge only)
@@ -181,6 +202,10 @@ func (k *Knowledge) GetMetadata() map[string]string {
 		return nil
 	}
 	for k, v := range metadataMap {
+		// The ACL is access control, not a property of the document
+		if k == KnowledgeACLMetadataKey {
+			continue
+		}
 		metadata[k] = fmt.Sprintf("%v", v)
 	}
 	return metadata
@@ -276,17 +301,19 @@ func (k *Knowledge) ManualMetadata() (*ManualKnowledgeMetadata, error) {
 }
 
 // SetManualMetadata sets manual knowledge metadata onto the knowledge instance.
+// The knowledge's ACL is kept.
 func (k *Knowledge) SetManualMetadata(meta *ManualKnowledgeMetadata) error {
+	acl := k.rawACL()
 	if meta == nil {
 		k.Metadata = nil
-		return nil
+		return k.setRawACL(acl)
 	}
 	jsonValue, err := meta.ToJSON()
 	if err != nil {
 		return err
 	}
 	k.Metadata = jsonValue
-	return nil
+	return k.setRawACL(acl)
 }
 
 // SetLastFAQImportResult sets FAQ import result to the dedicated field.
@@ -413,3 +440,76 @@ type KnowledgeCheckParams struct {
 	// Knowledge type
 	Type string
 }
+
+// FileReencryptResult summarizes re-encrypting a knowledge base's files with
+// its storage's current server-side encryption key.
+type FileReencryptResult struct {
+	// KeyID is the key the files are now encrypted with.
+	KeyID string `json:"key_id"`
+	// Reencrypted counts files rewritten under KeyID.
+	Reencrypted int `json:"reencrypted"`
+	// Skipped counts files that were already under KeyID or live in a
+	// different storage provider than the knowledge base now uses.
+	Skipped int `json:"skipped"`
+	// FailedKnowledgeIDs lists knowledge whose file could not be rewritten.
+	FailedKnowledgeIDs []string `json:"failed_knowledge_ids"`
+}
+
+// FileMigrationRequest moves a knowledge base's stored files to another
+// storage provider configured for the tenant.
+type FileMigrationRequest struct {
+	// TargetProvider is the provider the files move to, e.g. "tos".
+	TargetProvider string `json:"target_provider" binding:"required"`
+	// DeleteSource removes the original objects once the new paths are
+	// committed. The originals are kept by default.
+	DeleteSource bool `json:"delete_source"`
+	// DryRun only counts the files that would be moved.
+	DryRun bool `json:"dry_run"`
+}
+
+// FileMigrationResult summarizes moving a knowledge base's files to another
+// storage provider.
+type FileMigrationResult struct {
+	TargetProvider string `json:"target_provider"`
+	// Migrated counts files copied, verified and re-pointed to the target.
+	Migrated int `json:"migrated"`
+	// Skipped counts files already stored with the target provider.
+	Skipped int `json:"skipped"`
+	// FailedKnowledgeIDs lists knowledge whose file could not be copied or
+	// did not match its checksum after the copy. When any file fails no path
+	// is rewritten, so the migration can simply be retried.
+	FailedKnowledgeIDs []string `json:"failed_knowledge_ids"`
+	// Committed reports whether the new paths were written and the knowledge
+	// base switched to TargetProvider.
+	Committed bool `json:"committed"`
+}
+
+// KnowledgeFileMove is one knowledge file copied to a new storage path.
+type KnowledgeFileMove struct {
+	KnowledgeID string
+	// OldPath is the path the copy was made from; the row is only rewritten
+	// while it still points there.
+	OldPath string
+	NewPath string
+	// EncryptionKeyID is the server-side encryption key of the new object.
+	EncryptionKeyID string
+}
+
+// CodeArchiveImportResult summarizes importing the source files of a zip
+// archive of a code repository.
+type CodeArchiveImportResult struct {
+	// Imported lists the knowledge created, one per source file.
+	Imported []*Knowledge `json:"imported"`
+	// Skipped counts entries that are not imported: unsupported languages,
+	// hidden files, dependency and build directories, oversized files.
+	Skipped int `json:"skipped"`
+	// Failed lists the source files that could not be imported.
+	Failed []CodeArchiveFailure `json:"failed"`
+}
+
+// CodeArchiveFailure is a source file of a code archive that failed to
+// import.
+type CodeArchiveFailure struct {
+	FilePath string `json:"file_path"`
+	Error    string `json:"error"`
+}
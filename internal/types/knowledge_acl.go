@@ -0,0 +1,169 @@
+package types
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+)
+
+// KnowledgeACLMetadataKey is the knowledge metadata key holding its ACL.
+// The key is reserved: metadata supplied on upload cannot set it.
+const KnowledgeACLMetadataKey = "acl"
+
+// KnowledgeACLGroupAPIKey is the group of requests authenticated with the
+// tenant's API key; the other groups are the tenant roles.
+const KnowledgeACLGroupAPIKey = TagAccessSubjectAPIKey
+
+// KnowledgeACLSSOGroupPrefix prefixes the ACL groups naming external groups
+// of SSO users, e.g. "sso:finance".
+const KnowledgeACLSSOGroupPrefix = "sso:"
+
+// SSOGroupACLName returns the ACL group naming an external SSO group.
+func SSOGroupACLName(group string) string {
+	return KnowledgeACLSSOGroupPrefix + group
+}
+
+// KnowledgeACL lists who may read a document at retrieval time. A document
+// without an ACL is readable by everyone who can search its knowledge base.
+type KnowledgeACL struct {
+	// Users are user IDs
+	Users []string `json:"users"`
+	// Groups are tenant roles of the tenant owning the document, api_key, or
+	// external SSO groups prefixed with sso:
+	Groups []string `json:"groups"`
+}
+
+// IsEmpty reports whether the ACL lists nobody.
+func (a *KnowledgeACL) IsEmpty() bool {
+	return a == nil || (len(a.Users) == 0 && len(a.Groups) == 0)
+}
+
+// RetrievalPrincipal is the caller a retrieval runs for.
+type RetrievalPrincipal struct {
+	// TenantID is the caller's tenant; groups only match documents of it
+	TenantID uint64
+	// UserID is empty for API key requests
+	UserID string
+	// Groups are the caller's tenant role, api_key for API key requests and
+	// the SSO groups of the user
+	Groups []string
+}
+
+// RetrievalPrincipalFromContext returns the principal of the caller in ctx.
+func RetrievalPrincipalFromContext(ctx context.Context) *RetrievalPrincipal {
+	tenantID, _ := TenantIDFromContext(ctx)
+	principal := &RetrievalPrincipal{TenantID: tenantID}
+	for _, subject := range TagAccessSubjectsFromContext(ctx) {
+		switch subject.Type {
+		case TagAccessSubjectUser:
+			principal.UserID = subject.ID
+		case TagAccessSubjectAPIKey:
+			principal.Groups = append(principal.Groups, KnowledgeACLGroupAPIKey)
+		case TagAccessSubjectRole:
+			principal.Groups = append(principal.Groups, subject.ID)
+		}
+	}
+	if user, ok := ctx.Value(UserContextKey).(*User); ok && user != nil && principal.UserID != "" {
+		for _, group := range user.SSOGroups {
+			principal.Groups = append(principal.Groups, SSOGroupACLName(group))
+		}
+	}
+	return principal
+}
+
+// ACL returns the ACL of the knowledge, nil when it has none. An ACL that
+// cannot be parsed lists nobody, so the document stays hidden rather than
+// becoming public.
+func (k *Knowledge) ACL() *KnowledgeACL {
+	raw := k.rawACL()
+	if raw == nil {
+		return nil
+	}
+	var acl KnowledgeACL
+	if err := json.Unmarshal(raw, &acl); err != nil {
+		return &KnowledgeACL{}
+	}
+	return &acl
+}
+
+// SetACL stores the ACL in the metadata, or removes it when acl is empty.
+func (k *Knowledge) SetACL(acl *KnowledgeACL) error {
+	if acl.IsEmpty() {
+		return k.setRawACL(nil)
+	}
+	raw, err := json.Marshal(acl)
+	if err != nil {
+		return err
+	}
+	return k.setRawACL(raw)
+}
+
+// ReadableBy reports whether the principal may read the knowledge.
+func (k *Knowledge) ReadableBy(principal *RetrievalPrincipal) bool {
+	acl := k.ACL()
+	if acl == nil {
+		return true
+	}
+	if principal == nil {
+		return false
+	}
+	if principal.UserID != "" && slices.Contains(acl.Users, principal.UserID) {
+		return true
+	}
+	// Roles are per tenant; a caller reaching a shared knowledge base from
+	// another tenant is matched by user only.
+	if principal.TenantID != k.TenantID {
+		return false
+	}
+	for _, group := range principal.Groups {
+		if slices.Contains(acl.Groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// rawACL returns the raw ACL entry of the metadata, nil when absent.
+func (k *Knowledge) rawACL() json.RawMessage {
+	if len(k.Metadata) == 0 {
+		return nil
+	}
+	var metadata map[string]json.RawMessage
+	if err := json.Unmarshal(k.Metadata, &metadata); err != nil {
+		return nil
+	}
+	raw, ok := metadata[KnowledgeACLMetadataKey]
+	if !ok || string(raw) == "null" {
+		return nil
+	}
+	return raw
+}
+
+// setRawACL writes the raw ACL entry into the metadata, removing it when raw
+// is nil. The other metadata entries are kept as they are.
+func (k *Knowledge) setRawACL(raw json.RawMessage) error {
+	metadata := map[string]json.RawMessage{}
+	if len(k.Metadata) > 0 {
+		if err := json.Unmarshal(k.Metadata, &metadata); err != nil {
+			return err
+		}
+	}
+	if raw == nil {
+		if _, ok := metadata[KnowledgeACLMetadataKey]; !ok {
+			return nil
+		}
+		delete(metadata, KnowledgeACLMetadataKey)
+	} else {
+		metadata[KnowledgeACLMetadataKey] = raw
+	}
+	if len(metadata) == 0 {
+		k.Metadata = nil
+		return nil
+	}
+	bytes, err := json.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	k.Metadata = JSON(bytes)
+	return nil
+}
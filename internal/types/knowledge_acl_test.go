@@ -0,0 +1,70 @@
+package types
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKnowledgeACL_SetKeepsOtherMetadata(t *testing.T) {
+	k := &Knowledge{Metadata: JSON(`{"author":"a"}`)}
+	require.NoError(t, k.SetACL(&KnowledgeACL{Users: []string{"u1"}}))
+	assert.Equal(t, []string{"u1"}, k.ACL().Users)
+	assert.Equal(t, map[string]string{"author": "a"}, k.GetMetadata(), "the ACL is not document metadata")
+
+	require.NoError(t, k.SetACL(&KnowledgeACL{}))
+	assert.Nil(t, k.ACL())
+	assert.JSONEq(t, `{"author":"a"}`, string(k.Metadata))
+}
+
+func TestKnowledgeACL_ManualMetadataKeepsACL(t *testing.T) {
+	k := &Knowledge{}
+	require.NoError(t, k.SetACL(&KnowledgeACL{Groups: []string{"admin"}}))
+	require.NoError(t, k.SetManualMetadata(NewManualKnowledgeMetadata("# hi", ManualKnowledgeStatusDraft, 1)))
+	assert.Equal(t, []string{"admin"}, k.ACL().Groups)
+	meta, err := k.ManualMetadata()
+	require.NoError(t, err)
+	assert.Equal(t, "# hi", meta.Content)
+}
+
+func TestKnowledge_ReadableBy(t *testing.T) {
+	k := &Knowledge{TenantID: 1}
+	assert.True(t, k.ReadableBy(nil), "no ACL, readable by everyone")
+
+	require.NoError(t, k.SetACL(&KnowledgeACL{Users: []string{"u1"}, Groups: []string{"admin"}}))
+	assert.True(t, k.ReadableBy(&RetrievalPrincipal{TenantID: 1, UserID: "u1"}))
+	assert.True(t, k.ReadableBy(&RetrievalPrincipal{TenantID: 1, UserID: "u2", Groups: []string{"admin"}}))
+	assert.False(t, k.ReadableBy(&RetrievalPrincipal{TenantID: 1, UserID: "u2", Groups: []string{"viewer"}}))
+	assert.False(t, k.ReadableBy(&RetrievalPrincipal{TenantID: 2, UserID: "u2", Groups: []string{"admin"}}),
+		"roles of another tenant do not match")
+	assert.True(t, k.ReadableBy(&RetrievalPrincipal{TenantID: 2, UserID: "u1"}), "users match across tenants")
+	assert.False(t, k.ReadableBy(nil))
+
+	k.Metadata = JSON(`{"acl":"garbled"}`)
+	assert.False(t, k.ReadableBy(&RetrievalPrincipal{TenantID: 1, UserID: "u1"}), "an unreadable ACL hides the document")
+}
+
+func TestRetrievalPrincipalFromContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), TenantIDContextKey, uint64(7))
+	ctx = context.WithValue(ctx, UserIDContextKey, "u1")
+	ctx = context.WithValue(ctx, TenantRoleContextKey, TenantRoleViewer)
+	assert.Equal(t, &RetrievalPrincipal{TenantID: 7, UserID: "u1", Groups: []string{"viewer"}},
+		RetrievalPrincipalFromContext(ctx))
+
+	ctx = context.WithValue(ctx, UserContextKey, &User{ID: "u1", SSOGroups: StringArray{"finance"}})
+	assert.Equal(t, &RetrievalPrincipal{TenantID: 7, UserID: "u1", Groups: []string{"viewer", "sso:finance"}},
+		RetrievalPrincipalFromContext(ctx))
+
+	ctx = context.WithValue(ctx, APIKeyAuthContextKey, true)
+	ctx = context.WithValue(ctx, TenantRoleContextKey, TenantRoleAdmin)
+	assert.Equal(t, &RetrievalPrincipal{TenantID: 7, Groups: []string{KnowledgeACLGroupAPIKey, "admin"}},
+		RetrievalPrincipalFromContext(ctx))
+}
+
+func TestRetrieveParams_ExcludedKnowledgeIDs(t *testing.T) {
+	assert.Nil(t, RetrieveParams{}.ExcludedKnowledgeIDs())
+	assert.Equal(t, []string{"a", "b"},
+		RetrieveParams{ExcludeKnowledgeIDs: []string{"a"}, ACLDeniedKnowledgeIDs: []string{"b"}}.ExcludedKnowledgeIDs())
+}
@@ -0,0 +1,159 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// Knowledge gap report defaults and limits.
+const (
+	// DefaultKnowledgeGapThreshold is the cosine similarity above which two
+	// gap queries ask about the same missing content
+	DefaultKnowledgeGapThreshold = 0.85
+	MinKnowledgeGapThreshold     = 0.5
+	// DefaultKnowledgeGapClusters is how many clusters a report returns
+	// without a limit
+	DefaultKnowledgeGapClusters = 20
+	MaxKnowledgeGapClusters     = 100
+	// MaxKnowledgeGapsClustered bounds the gaps one report clusters; the
+	// most frequent ones are taken
+	MaxKnowledgeGapsClustered = 500
+)
+
+// KnowledgeGap is a query that searched a knowledge base and retrieved
+// nothing above the retrieval thresholds. The same question asked again
+// adds to its count, so the gaps rank what users miss most.
+type KnowledgeGap struct {
+	ID              string `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64 `json:"tenant_id"         gorm:"uniqueIndex:idx_knowledge_gaps_query"`
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);uniqueIndex:idx_knowledge_gaps_query"`
+	// QueryHash identifies the normalized query, as in QueryLog
+	QueryHash string `json:"-" gorm:"type:varchar(64);uniqueIndex:idx_knowledge_gaps_query"`
+	// Query is the latest wording of the question
+	Query string `json:"query" gorm:"type:text"`
+	// Count is how many times the question retrieved nothing
+	Count       int64     `json:"count"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"  gorm:"index"`
+	// Embedding is the vector of Query by the embedding model of the
+	// knowledge base, computed when a report first clusters the gap
+	Embedding        KnowledgeGapVector `json:"-" gorm:"type:json"`
+	EmbeddingModelID string             `json:"-" gorm:"type:varchar(64)"`
+}
+
+// TableName returns the table name of KnowledgeGap
+func (KnowledgeGap) TableName() string {
+	return "knowledge_gaps"
+}
+
+// KnowledgeGapVector is stored as a JSON column.
+type KnowledgeGapVector []float32
+
+// Value implements the driver.Valuer interface
+func (v KnowledgeGapVector) Value() (driver.Value, error) {
+	return json.Marshal(v)
+}
+
+// Scan implements the sql.Scanner interface
+func (v *KnowledgeGapVector) Scan(value interface{}) error {
+	switch data := value.(type) {
+	case []byte:
+		return json.Unmarshal(data, v)
+	case string:
+		return json.Unmarshal([]byte(data), v)
+	}
+	return nil
+}
+
+// NewKnowledgeGaps returns a gap in each knowledge base a zero-result query
+// searched, or nil when the query retrieved something.
+func NewKnowledgeGaps(log *QueryLog, seenAt time.Time) []*KnowledgeGap {
+	if !log.ZeroResult {
+		return nil
+	}
+	gaps := make([]*KnowledgeGap, 0, len(log.KnowledgeBases))
+	for _, kb := range log.KnowledgeBases {
+		gaps = append(gaps, &KnowledgeGap{
+			TenantID:        log.TenantID,
+			KnowledgeBaseID: kb.KnowledgeBaseID,
+			QueryHash:       log.QueryHash,
+			Query:           log.Query,
+			Count:           1,
+			FirstSeenAt:     seenAt,
+			LastSeenAt:      seenAt,
+		})
+	}
+	return gaps
+}
+
+// KnowledgeGapReportRequest tunes a knowledge gap report.
+type KnowledgeGapReportRequest struct {
+	// Threshold is the similarity at which gaps are clustered, between
+	// MinKnowledgeGapThreshold and 1; 0 means DefaultKnowledgeGapThreshold
+	Threshold float64
+	// Limit is the number of clusters to return; 0 means DefaultKnowledgeGapClusters
+	Limit int
+}
+
+// KnowledgeGapCluster is a group of gaps asking about the same missing
+// content, represented by its most frequent query.
+type KnowledgeGapCluster struct {
+	Query       string          `json:"query"`
+	Count       int64           `json:"count"`
+	FirstSeenAt time.Time       `json:"first_seen_at"`
+	LastSeenAt  time.Time       `json:"last_seen_at"`
+	Gaps        []*KnowledgeGap `json:"gaps"`
+}
+
+// NewKnowledgeGapCluster sums up gaps, the most frequent one first.
+func NewKnowledgeGapCluster(gaps []*KnowledgeGap) *KnowledgeGapCluster {
+	cluster := &KnowledgeGapCluster{Gaps: gaps}
+	for _, gap := range gaps {
+		cluster.Count += gap.Count
+		if cluster.FirstSeenAt.IsZero() || gap.FirstSeenAt.Before(cluster.FirstSeenAt) {
+			cluster.FirstSeenAt = gap.FirstSeenAt
+		}
+		if gap.LastSeenAt.After(cluster.LastSeenAt) {
+			cluster.LastSeenAt = gap.LastSeenAt
+		}
+	}
+	if len(gaps) > 0 {
+		cluster.Query = gaps[0].Query
+	}
+	return cluster
+}
+
+// SortKnowledgeGapClusters orders clusters by total count, then by the
+// latest occurrence.
+func SortKnowledgeGapClusters(clusters []*KnowledgeGapCluster) {
+	sort.SliceStable(clusters, func(i, j int) bool {
+		if clusters[i].Count != clusters[j].Count {
+			return clusters[i].Count > clusters[j].Count
+		}
+		return clusters[i].LastSeenAt.After(clusters[j].LastSeenAt)
+	})
+}
+
+// KnowledgeGapReport groups the gaps of a knowledge base into clusters of
+// similar questions, most frequent first.
+type KnowledgeGapReport struct {
+	KnowledgeBaseID string  `json:"knowledge_base_id"`
+	Threshold       float64 `json:"threshold"`
+	// Gaps is the number of distinct gap queries of the knowledge base
+	Gaps int64 `json:"gaps"`
+	// Clustered is false when the queries could not be embedded and each
+	// cluster holds one query
+	Clustered bool `json:"clustered"`
+	// Truncated is set when the knowledge base had more gaps than a report
+	// clusters; the least frequent were left out
+	Truncated bool                   `json:"truncated"`
+	Clusters  []*KnowledgeGapCluster `json:"clusters"`
+}
+
+// KnowledgeGapDismissRequest dismisses gaps once the missing content was
+// added. A dismissed query that still retrieves nothing is recorded again.
+type KnowledgeGapDismissRequest struct {
+	GapIDs []string `json:"gap_ids" binding:"required,min=1"`
+}
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewKnowledgeGaps(t *testing.T) {
+	now := time.Now()
+	log := &QueryLog{
+		TenantID: 1, Query: "q", QueryHash: QueryHash("q"),
+		KnowledgeBases: []*QueryLogKnowledgeBase{{KnowledgeBaseID: "kb1"}, {KnowledgeBaseID: "kb2"}},
+	}
+	assert.Nil(t, NewKnowledgeGaps(log, now), "queries that retrieved something are no gaps")
+
+	log.ZeroResult = true
+	gaps := NewKnowledgeGaps(log, now)
+	require.Len(t, gaps, 2)
+	assert.Equal(t, "kb2", gaps[1].KnowledgeBaseID)
+	assert.Equal(t, int64(1), gaps[1].Count)
+	assert.Equal(t, log.QueryHash, gaps[1].QueryHash)
+}
+
+func TestKnowledgeGapClusters(t *testing.T) {
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	big := NewKnowledgeGapCluster([]*KnowledgeGap{
+		{Query: "parking rules", Count: 3, FirstSeenAt: day.AddDate(0, 0, 2), LastSeenAt: day.AddDate(0, 0, 3)},
+		{Query: "where to park", Count: 2, FirstSeenAt: day, LastSeenAt: day.AddDate(0, 0, 5)},
+	})
+	assert.Equal(t, "parking rules", big.Query)
+	assert.Equal(t, int64(5), big.Count)
+	assert.True(t, big.FirstSeenAt.Equal(day))
+	assert.True(t, big.LastSeenAt.Equal(day.AddDate(0, 0, 5)))
+
+	old := NewKnowledgeGapCluster([]*KnowledgeGap{{Query: "a", Count: 5, LastSeenAt: day}})
+	small := NewKnowledgeGapCluster([]*KnowledgeGap{{Query: "b", Count: 1, LastSeenAt: day}})
+	clusters := []*KnowledgeGapCluster{small, old, big}
+	SortKnowledgeGapClusters(clusters)
+	assert.Equal(t, []*KnowledgeGapCluster{big, old, small}, clusters, "ties go to the latest seen")
+}
@@ -0,0 +1,44 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+// KnowledgeVersion records one file version of a knowledge. Version 1 is the
+// file the knowledge was created from; every file uploaded over the knowledge
+// adds a version. The chunk counts compare the version with the previous one
+// and are filled in once the version has been indexed.
+type KnowledgeVersion struct {
+	ID          string `json:"id"           gorm:"type:varchar(36);primaryKey"`
+	TenantID    uint64 `json:"tenant_id"    gorm:"index"`
+	KnowledgeID string `json:"knowledge_id" gorm:"type:varchar(36);index"`
+	Version     int    `json:"version"`
+	FileName    string `json:"file_name"`
+	FileType    string `json:"file_type"`
+	FileSize    int64  `json:"file_size"`
+	FileHash    string `json:"file_hash"`
+	// ChunksAdded are the chunks created and embedded for this version
+	ChunksAdded int `json:"chunks_added"`
+	// ChunksReused are the chunks of the previous version kept unchanged,
+	// with their IDs and vectors
+	ChunksReused int `json:"chunks_reused"`
+	// ChunksRemoved are the chunks of the previous version deleted
+	ChunksRemoved int       `json:"chunks_removed"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// TableName returns the table name of KnowledgeVersion
+func (KnowledgeVersion) TableName() string {
+	return "knowledge_versions"
+}
+
+// DocumentChunkHash returns the content hash of a document chunk: the hash
+// of the text its vector is computed from, the title prefix followed by the
+// chunk's EmbeddingContent. Chunks with the same hash in two versions of a
+// document can share the same vector.
+func DocumentChunkHash(titlePrefix string, chunk *Chunk) string {
+	sum := sha256.Sum256([]byte(titlePrefix + chunk.EmbeddingContent()))
+	return hex.EncodeToString(sum[:])
+}
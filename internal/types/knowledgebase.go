@@ -88,9 +88,20 @@ type KnowledgeBase struct {
 	QuestionGenerationConfig *QuestionGenerationConfig `yaml:"question_generation_config" json:"question_generation_config" gorm:"column:question_generation_config;type:json"`
 	// WikiConfig stores wiki-specific configuration (only for wiki type knowledge bases)
 	WikiConfig *WikiConfig `yaml:"wiki_config"             json:"wiki_config"             gorm:"column:wiki_config;type:json"`
+	// AutoTagConfig controls automatic tagging of documents against the knowledge base's tags
+	AutoTagConfig *AutoTagConfig `yaml:"auto_tag_config"         json:"auto_tag_config"         gorm:"column:auto_tag_config;type:json"`
 	// IndexingStrategy controls which indexing pipelines are active for this knowledge base.
 	// Pipelines: vector search, keyword search, wiki generation, knowledge graph extraction.
 	IndexingStrategy IndexingStrategy `yaml:"indexing_strategy"       json:"indexing_strategy"       gorm:"column:indexing_strategy;type:json"`
+	// IndexGeneration is the chunk generation retrieval reads from. Chunks
+	// stamped with a newer generation belong to an in-flight index migration
+	// and stay invisible until the migration switches the KB over to them.
+	// Both generation fields are written only through the dedicated
+	// repository methods (`<-:create`), never by a whole-row Save.
+	IndexGeneration int64 `yaml:"-"                       json:"index_generation"        gorm:"column:index_generation;default:0;<-:create"`
+	// PendingIndexGeneration is the generation being built by the running
+	// index migration, 0 when none is running.
+	PendingIndexGeneration int64 `yaml:"-"                       json:"pending_index_generation" gorm:"column:pending_index_generation;default:0;<-:create"`
 	// IsPinned and PinnedAt are computed per-caller from user_kb_pins
 	// (see migration 000050). They used to be stored on the row itself,
 	// which made pinning a tenant-wide ordering decision gated behind
@@ -135,6 +146,9 @@ type KnowledgeBaseConfig struct {
 	FAQConfig *FAQConfig `yaml:"faq_config"              json:"faq_config"`
 	// Wiki configuration (only for wiki-enabled knowledge bases)
 	WikiConfig *WikiConfig `yaml:"wiki_config"             json:"wiki_config"`
+	// Auto-tagging configuration (only for document knowledge bases).
+	// nil means "no change" when updating.
+	AutoTagConfig *AutoTagConfig `yaml:"auto_tag_config"         json:"auto_tag_config"`
 	// IndexingStrategy controls which indexing pipelines are active.
 	// nil means "no change" when updating (preserves existing strategy).
 	IndexingStrategy *IndexingStrategy `yaml:"indexing_strategy"       json:"indexing_strategy"`
@@ -170,7 +184,9 @@ type ChunkingConfig struct {
 	// Strategy selects the adaptive chunking tier. Empty / "legacy" preserves
 	// the historical recursive splitter; "auto" lets a profiler pick between
 	// heading-aware, heuristic and recursive tiers; "heading" / "heuristic" /
-	// "recursive" pin the tier explicitly.
+	// "recursive" pin the tier explicitly. "markdown" splits at every
+	// heading, never splits fenced code blocks and records each chunk's
+	// heading path in its metadata.
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
 	// TokenLimit caps chunk size in approximate tokens. 0 = use ChunkSize
 	// as a character count.
@@ -178,6 +194,36 @@ type ChunkingConfig struct {
 	// Languages hints the heuristic patterns. Empty = auto-detect from content.
 	// Examples: ["de"], ["en", "zh"].
 	Languages []string `yaml:"languages,omitempty" json:"languages,omitempty"`
+	// ContextWindow is the number of chunks before and after each retrieved
+	// chunk that are stitched into the answer context, so that answers are
+	// not cut off mid-sentence. 0 disables it; capped at MaxChunkContextWindow.
+	// Parent-child chunks are not expanded, their parent already provides
+	// the surrounding context.
+	ContextWindow int `yaml:"context_window,omitempty" json:"context_window,omitempty"`
+	// TableChunking keeps every table of a document as a chunk of its own,
+	// converted to Markdown where possible and tagged with its columns and
+	// row range in the chunk metadata. Tables larger than four times the
+	// chunk size are split between rows. Not used in parent-child mode.
+	TableChunking bool `yaml:"table_chunking,omitempty" json:"table_chunking,omitempty"`
+	// TableSummary additionally asks the knowledge base's summary model for a
+	// short description of each table, indexed alongside the table chunk so
+	// that questions phrased in prose find it. Requires TableChunking.
+	TableSummary bool `yaml:"table_summary,omitempty" json:"table_summary,omitempty"`
+	// ContextualRetrieval asks the knowledge base's summary model for a
+	// one or two sentence preamble situating each chunk within its document.
+	// The preamble is stored in the chunk metadata and embedded together
+	// with the chunk, so that chunks which only make sense in context (e.g.
+	// "revenue grew 3%") are still found by questions naming the subject.
+	ContextualRetrieval bool `yaml:"contextual_retrieval,omitempty" json:"contextual_retrieval,omitempty"`
+}
+
+// MaxChunkContextWindow caps ChunkingConfig.ContextWindow.
+const MaxChunkContextWindow = 5
+
+// EffectiveContextWindow returns ContextWindow clamped to
+// [0, MaxChunkContextWindow].
+func (c ChunkingConfig) EffectiveContextWindow() int {
+	return max(0, min(c.ContextWindow, MaxChunkContextWindow))
 }
 
 // ResolveParserEngine returns the engine name for the given file type
@@ -197,7 +243,7 @@ func (c ChunkingConfig) ResolveParserEngine(fileType string) string {
 // StorageProviderConfig stores the KB-level storage provider selection.
 // Credentials are managed at the tenant level (StorageEngineConfig).
 type StorageProviderConfig struct {
-	Provider string `yaml:"provider" json:"provider"` // "local", "minio", "cos", "tos", "s3", "oss", "ks3", "obs"
+	Provider string `yaml:"provider" json:"provider"` // "local", "minio", "cos", "tos", "s3", "oss", "ks3", "obs", "gcs"
 }
 
 func (c StorageProviderConfig) Value() (driver.Value, error) {
@@ -335,7 +381,7 @@ func InferStorageFromFilePath(filePath string) string {
 // e.g. "minio://bucket/key" → "minio", "local://tenant/file.pdf" → "local"
 // Returns "" if the path does not use a known provider scheme.
 func ParseProviderScheme(filePath string) string {
-	for _, provider := range []string{"local", "minio", "cos", "tos", "s3", "oss", "ks3", "obs"} {
+	for _, provider := range []string{"local", "minio", "cos", "tos", "s3", "oss", "ks3", "obs", "gcs"} {
 		if strings.HasPrefix(filePath, provider+"://") {
 			return provider
 		}
@@ -421,6 +467,9 @@ type QuestionGenerationConfig struct {
 	Enabled bool `yaml:"enabled"  json:"enabled"`
 	// Number of questions to generate per chunk (default: 3, max: 10)
 	QuestionCount int `yaml:"question_count" json:"question_count"`
+	// Chat model used to generate the questions; empty uses the knowledge
+	// base's summary model
+	ModelID string `yaml:"model_id" json:"model_id,omitempty"`
 }
 
 // Value implements the driver.Valuer interface
@@ -462,6 +511,8 @@ type ASRConfig struct {
 	Enabled  bool   `yaml:"enabled"  json:"enabled"`
 	ModelID  string `yaml:"model_id" json:"model_id"`
 	Language string `yaml:"language" json:"language"` // optional: language hint for transcription
+	// ChunkSeconds bounds the time window of a transcript chunk; 0 uses the default (60s)
+	ChunkSeconds int `yaml:"chunk_seconds" json:"chunk_seconds,omitempty"`
 }
 
 // IsASREnabled checks if ASR is enabled with a valid model
@@ -724,3 +775,14 @@ func (kb *KnowledgeBase) SharesStoreWith(other *KnowledgeBase) bool {
 		return *a == *b
 	}
 }
+
+// IndexMigrationRequest starts rebuilding a knowledge base's chunks and
+// index as a new index generation.
+type IndexMigrationRequest struct {
+	// Rechunk re-splits documents with the current chunking config instead
+	// of copying the existing chunks.
+	Rechunk bool `json:"rechunk"`
+	// EmbeddingModelID switches the knowledge base to another embedding
+	// model; empty keeps the current one.
+	EmbeddingModelID string `json:"embedding_model_id"`
+}
@@ -315,3 +315,11 @@ func TestEffectiveStorageProvider_CrossBackendDetection(t *testing.T) {
 			dstSame.EffectiveStorageProvider(tenantDefault), sp)
 	}
 }
+
+func TestChunkingConfig_EffectiveContextWindow(t *testing.T) {
+	for in, want := range map[int]int{-1: 0, 0: 0, 2: 2, MaxChunkContextWindow + 3: MaxChunkContextWindow} {
+		if got := (ChunkingConfig{ContextWindow: in}).EffectiveContextWindow(); got != want {
+			t.Errorf("EffectiveContextWindow(%d) = %d, want %d", in, got, want)
+		}
+	}
+}
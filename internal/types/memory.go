@@ -1,19 +1,152 @@
 package types
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrMemoryUnavailable is returned when the memory graph is not configured.
+	ErrMemoryUnavailable = errors.New("memory repository is not available")
+	// ErrMemoryNotFound is returned when a memory does not exist or does not
+	// belong to the user.
+	ErrMemoryNotFound = errors.New("memory not found")
+)
 
 // Episode represents a conversation episode or a distinct interaction event
 type Episode struct {
 	ID        string    `json:"id"`
+	TenantID  uint64    `json:"tenant_id"`
 	UserID    string    `json:"user_id"`
 	SessionID string    `json:"session_id"`
 	Summary   string    `json:"summary"`
 	CreatedAt time.Time `json:"created_at"`
+	// Salience is the LLM-rated long-term importance of the episode, 0 to 1.
+	Salience float64 `json:"salience"`
+	// AccessCount and LastAccessedAt track how often and how recently the
+	// episode was retrieved; the forgetting policy favours episodes in use.
+	AccessCount    int       `json:"access_count"`
+	LastAccessedAt time.Time `json:"last_accessed_at"`
+	// Archived episodes were forgotten by the archive policy and are no
+	// longer retrieved.
+	Archived bool `json:"archived"`
+	// Embeddings are stored alongside the episode for semantic retrieval;
+	// nil when the tenant has no embedding model.
+	Embeddings *MemoryEmbeddings `json:"-"`
+}
+
+// MemoryEmbeddings are the vectors of an episode's summary and of the
+// descriptions of the entities it mentions.
+type MemoryEmbeddings struct {
+	// ModelID identifies the embedding model; vectors of different models
+	// are never compared.
+	ModelID string
+	Summary []float32
+	// Entities is keyed by entity title.
+	Entities map[string][]float32
 }
 
 // MemoryContext represents the retrieved memory context for a conversation
 type MemoryContext struct {
-	RelatedEpisodes []Episode      `json:"related_episodes"`
-	RelatedEntities []Entity       `json:"related_entities"`
+	RelatedEpisodes  []Episode      `json:"related_episodes"`
+	RelatedEntities  []Entity       `json:"related_entities"`
 	RelatedRelations []Relationship `json:"related_relations"`
 }
+
+// MemoryEntity is an entity node of the memory graph as seen by the
+// consolidation job.
+type MemoryEntity struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	// Aliases lists the names of duplicates merged into this entity.
+	Aliases []string `json:"aliases"`
+	// Mentions counts the episodes that mention the entity.
+	Mentions int `json:"mentions"`
+}
+
+// Profile fact categories.
+const (
+	ProfileCategoryName       = "name"
+	ProfileCategoryPreference = "preference"
+	ProfileCategoryRole       = "role"
+	ProfileCategoryProject    = "project"
+	ProfileCategoryOther      = "other"
+)
+
+// UserProfileFact is a stable fact about a user, such as their name, a
+// preference or a project they work on. Unlike episodes, facts are updated
+// in place as conversations refine them.
+type UserProfileFact struct {
+	Category string `json:"category"`
+	// Key identifies the fact within its category, e.g. "preferred_language".
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	// SourceEpisodeIDs are the episodes the fact was learned from.
+	SourceEpisodeIDs []string  `json:"source_episode_ids"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// UserProfile is the semantic memory kept about a user.
+type UserProfile struct {
+	UserID string             `json:"user_id"`
+	Facts  []*UserProfileFact `json:"facts"`
+}
+
+// MemoryRelationship is a relationship between two memory graph entities.
+// Relationships are never overwritten: when a newer episode contradicts one,
+// it is closed by setting ValidTo and the new fact is recorded next to it,
+// so the graph keeps the history of what was true when.
+type MemoryRelationship struct {
+	ID          string  `json:"id"`
+	Source      string  `json:"source"`
+	Target      string  `json:"target"`
+	Description string  `json:"description"`
+	Weight      float64 `json:"weight"`
+	// ValidFrom is when the episode asserting the relationship happened;
+	// zero for relationships recorded before validity was tracked.
+	ValidFrom time.Time `json:"valid_from"`
+	// ValidTo is when a later episode contradicted the relationship; nil
+	// while it still holds.
+	ValidTo *time.Time `json:"valid_to,omitempty"`
+}
+
+// IsValidAt reports whether the relationship held at t.
+func (r *MemoryRelationship) IsValidAt(t time.Time) bool {
+	return !r.ValidFrom.After(t) && (r.ValidTo == nil || r.ValidTo.After(t))
+}
+
+// MemoryExport is everything remembered about a user.
+type MemoryExport struct {
+	UserID        string                `json:"user_id"`
+	ExportedAt    time.Time             `json:"exported_at"`
+	Profile       []*UserProfileFact    `json:"profile"`
+	Episodes      []*Episode            `json:"episodes"`
+	Entities      []*MemoryEntity       `json:"entities"`
+	Relationships []*MemoryRelationship `json:"relationships"`
+}
+
+// SessionSummary is the working memory of a chat session: a rolling summary
+// of the messages that no longer fit the context budget. It is extended
+// incrementally as the conversation grows, so older turns are folded in
+// once rather than re-summarized on every request.
+type SessionSummary struct {
+	TenantID  uint64 `json:"tenant_id"`
+	SessionID string `json:"session_id"`
+	UserID    string `json:"user_id"`
+	Summary   string `json:"summary"`
+	// SummarizedUntil is the creation time of the last message folded into
+	// the summary; later messages are still verbatim.
+	SummarizedUntil time.Time `json:"summarized_until"`
+	// MessageCount is the number of messages folded into the summary.
+	MessageCount int       `json:"message_count"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// MemoryUser identifies whose memory a part of the graph is. The graph is
+// partitioned by tenant: a user's memories in one tenant are never visible
+// from another.
+type MemoryUser struct {
+	TenantID uint64 `json:"tenant_id"`
+	UserID   string `json:"user_id"`
+}
@@ -0,0 +1,93 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MemoryExtractionConfig customizes, per tenant, how conversation memory is
+// extracted from each chat turn. Empty fields keep the built-in behaviour.
+type MemoryExtractionConfig struct {
+	// Prompt replaces the built-in extraction prompt. It is rendered with
+	// the {{conversation}}, {{known_facts}} and {{entity_types}}
+	// placeholders and must ask for the fields of the output schema.
+	Prompt string `json:"prompt"`
+	// EntityTypes is the taxonomy extracted entities are typed with, e.g.
+	// ["Person", "Project", "Tool"]. Entities of other types are typed
+	// "Other". Empty allows any type.
+	EntityTypes []string `json:"entity_types"`
+	// OutputSchema replaces the JSON schema the chat model is asked to
+	// answer in. It must keep the field names of the built-in schema
+	// (summary, salience, entities, relationships, profile_facts), which
+	// are the ones read back; other fields are ignored.
+	OutputSchema json.RawMessage `json:"output_schema,omitempty"`
+	// ModelIDs are the chat models memory extraction and summarization run
+	// on, most preferred first. Models that are missing or fail to load are
+	// skipped; when none is usable memory falls back to the tenant's
+	// KnowledgeQA models, then its vision chat models.
+	ModelIDs []string `json:"model_ids,omitempty"`
+}
+
+// MaxMemoryModels caps the memory model fallback chain.
+const MaxMemoryModels = 10
+
+// MemoryEntityTypeOther types entities outside the tenant's taxonomy.
+const MemoryEntityTypeOther = "Other"
+
+// Validate checks that the output schema, when set, is a JSON object and
+// that the model fallback chain is short and has no blank IDs.
+func (c *MemoryExtractionConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if len(c.ModelIDs) > MaxMemoryModels {
+		return fmt.Errorf("model_ids accepts at most %d models", MaxMemoryModels)
+	}
+	for _, id := range c.ModelIDs {
+		if strings.TrimSpace(id) == "" {
+			return errors.New("model_ids must not contain empty IDs")
+		}
+	}
+	if len(c.OutputSchema) == 0 {
+		return nil
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(c.OutputSchema, &schema); err != nil || schema == nil {
+		return errors.New("output_schema must be a JSON schema object")
+	}
+	return nil
+}
+
+// NormalizeEntityType maps an extracted entity type onto the taxonomy,
+// matching case-insensitively. Without a taxonomy the type is kept.
+func (c *MemoryExtractionConfig) NormalizeEntityType(entityType string) string {
+	if c == nil || len(c.EntityTypes) == 0 {
+		return entityType
+	}
+	for _, t := range c.EntityTypes {
+		if strings.EqualFold(strings.TrimSpace(t), strings.TrimSpace(entityType)) {
+			return t
+		}
+	}
+	return MemoryEntityTypeOther
+}
+
+// Value implements the driver.Valuer interface for database serialization
+func (c MemoryExtractionConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (c *MemoryExtractionConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
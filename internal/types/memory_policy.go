@@ -0,0 +1,97 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// MemoryTrigger decides when the turns of a session are stored in the
+// user's long-term memory.
+type MemoryTrigger string
+
+const (
+	// MemoryTriggerEveryTurn stores every answered turn as an episode.
+	MemoryTriggerEveryTurn MemoryTrigger = "every_turn"
+	// MemoryTriggerEveryNTurns stores every N turns together as one episode.
+	MemoryTriggerEveryNTurns MemoryTrigger = "every_n_turns"
+	// MemoryTriggerSessionEnd stores the session as one episode when it is
+	// ended through the API.
+	MemoryTriggerSessionEnd MemoryTrigger = "session_end"
+	// MemoryTriggerMemorable stores a turn only when the chat model judges
+	// it worth remembering.
+	MemoryTriggerMemorable MemoryTrigger = "memorable"
+	// MemoryTriggerExplicit stores only the turns whose request asks for it.
+	MemoryTriggerExplicit MemoryTrigger = "explicit"
+)
+
+// DefaultMemoryEveryNTurns is the batch size of MemoryTriggerEveryNTurns
+// when the policy does not set one.
+const DefaultMemoryEveryNTurns = 5
+
+// MemoryPolicy is the per-session setting of when conversation memory is
+// stored. A nil policy stores every turn. Whatever the policy, a request
+// with remember set is always stored, and the session's rolling summary is
+// kept up to date on every turn.
+type MemoryPolicy struct {
+	Trigger MemoryTrigger `json:"trigger"`
+	// EveryNTurns is the number of turns stored together by
+	// MemoryTriggerEveryNTurns.
+	EveryNTurns int `json:"every_n_turns,omitempty"`
+}
+
+// Validate checks the trigger and batch size.
+func (p *MemoryPolicy) Validate() error {
+	if p == nil {
+		return nil
+	}
+	switch p.Trigger {
+	case "", MemoryTriggerEveryTurn, MemoryTriggerEveryNTurns, MemoryTriggerSessionEnd,
+		MemoryTriggerMemorable, MemoryTriggerExplicit:
+	default:
+		return fmt.Errorf("unknown memory trigger %q", p.Trigger)
+	}
+	if p.EveryNTurns < 0 {
+		return fmt.Errorf("every_n_turns must not be negative")
+	}
+	return nil
+}
+
+// EffectiveTrigger returns the trigger, defaulting to MemoryTriggerEveryTurn.
+func (p *MemoryPolicy) EffectiveTrigger() MemoryTrigger {
+	if p == nil || p.Trigger == "" {
+		return MemoryTriggerEveryTurn
+	}
+	return p.Trigger
+}
+
+// BatchSize returns the number of turns MemoryTriggerEveryNTurns stores
+// together.
+func (p *MemoryPolicy) BatchSize() int {
+	if p == nil || p.EveryNTurns <= 0 {
+		return DefaultMemoryEveryNTurns
+	}
+	return p.EveryNTurns
+}
+
+// Value implements the driver.Valuer interface for database serialization
+func (p MemoryPolicy) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (p *MemoryPolicy) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(b, p)
+}
@@ -193,6 +193,14 @@ type Message struct {
 	Role string `json:"role"`
 	// References to knowledge chunks used in the response
 	KnowledgeReferences References `json:"knowledge_references"  gorm:"type:json,column:knowledge_references"`
+	// Sources cited inline as [n] in the answer (only for assistant messages)
+	Citations Citations `json:"citations,omitempty" gorm:"type:jsonb;column:citations"`
+	// Verification of the answer's claims against the retrieved context (only
+	// for assistant messages with answer verification enabled)
+	Verification *AnswerVerification `json:"verification,omitempty" gorm:"type:jsonb;column:verification"`
+	// Generation metadata, such as the chat model that answered (only for
+	// assistant messages)
+	Metadata *MessageMetadata `json:"metadata,omitempty" gorm:"type:jsonb;column:metadata"`
 	// Agent execution steps (only for assistant messages generated by agent)
 	// This contains the detailed reasoning process and tool calls made by the agent
 	// Stored for user history display, but NOT included in LLM context to avoid redundancy
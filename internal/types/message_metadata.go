@@ -0,0 +1,39 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// MessageMetadata records how an assistant message was generated
+type MessageMetadata struct {
+	// ModelID is the chat model that generated the answer
+	ModelID string `json:"model_id"`
+	// ModelName is the name of that model
+	ModelName string `json:"model_name,omitempty"`
+	// FallbackFrom is the chat model that failed before the answering one
+	// took over; empty when the configured model answered
+	FallbackFrom string `json:"fallback_from,omitempty"`
+}
+
+// Value implements the driver.Valuer interface for database serialization
+func (m MessageMetadata) Value() (driver.Value, error) {
+	return json.Marshal(m)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (m *MessageMetadata) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	var b []byte
+	switch val := value.(type) {
+	case []byte:
+		b = val
+	case string:
+		b = []byte(val)
+	default:
+		return nil
+	}
+	return json.Unmarshal(b, m)
+}
@@ -0,0 +1,215 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	// MaxPipelineEmbeddingTopK bounds the retrieval top-K of a pipeline config.
+	MaxPipelineEmbeddingTopK = 200
+	// MaxPipelineHistoryRounds bounds the history rounds of a pipeline config.
+	MaxPipelineHistoryRounds = 50
+	// MaxChatFallbackModels caps the chat model fallback chain.
+	MaxChatFallbackModels = 5
+)
+
+// PipelineConfig pins the behavior of the knowledge QA pipeline stages for a
+// tenant or a session. Every field is optional and unset fields keep the
+// value of the level below; levels apply in the order server defaults,
+// agent, tenant, session. A stored config therefore keeps the stages it sets
+// the same across later agent or server changes.
+type PipelineConfig struct {
+	Rewrite    *PipelineRewriteConfig    `json:"rewrite,omitempty"`
+	Retrieval  *PipelineRetrievalConfig  `json:"retrieval,omitempty"`
+	Rerank     *PipelineRerankConfig     `json:"rerank,omitempty"`
+	Memory     *PipelineMemoryConfig     `json:"memory,omitempty"`
+	History    *PipelineHistoryConfig    `json:"history,omitempty"`
+	Generation *PipelineGenerationConfig `json:"generation,omitempty"`
+}
+
+// PipelineRewriteConfig configures the query rewrite stage
+type PipelineRewriteConfig struct {
+	// Enabled turns multi-turn query rewriting on or off
+	Enabled *bool `json:"enabled,omitempty"`
+	// QueryExpansion turns keyword expansion of the query on or off
+	QueryExpansion *bool `json:"query_expansion,omitempty"`
+}
+
+// PipelineRetrievalConfig configures the chunk search stage
+type PipelineRetrievalConfig struct {
+	// TopK is the number of chunks retrieved (0 = keep)
+	TopK int `json:"top_k,omitempty"`
+	// VectorThreshold is the minimum vector similarity (0-1)
+	VectorThreshold *float64 `json:"vector_threshold,omitempty"`
+	// KeywordThreshold is the minimum keyword match score (0-1)
+	KeywordThreshold *float64 `json:"keyword_threshold,omitempty"`
+}
+
+// PipelineRerankConfig configures the rerank stage
+type PipelineRerankConfig struct {
+	// Enabled turns reranking on or off; turning it on needs a rerank model
+	// configured on the agent
+	Enabled *bool `json:"enabled,omitempty"`
+	// TopK is the number of reranked chunks kept (0 = keep)
+	TopK int `json:"top_k,omitempty"`
+	// Threshold is the minimum rerank score (-10 to 10)
+	Threshold *float64 `json:"threshold,omitempty"`
+}
+
+// PipelineMemoryConfig configures the long-term memory stages
+type PipelineMemoryConfig struct {
+	// Enabled turns memory retrieval and storage on or off
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// PipelineHistoryConfig configures the history loading stage
+type PipelineHistoryConfig struct {
+	// MaxRounds is the number of earlier rounds sent to the model (0 = none)
+	MaxRounds *int `json:"max_rounds,omitempty"`
+}
+
+// PipelineGenerationConfig configures the answer generation stage
+type PipelineGenerationConfig struct {
+	// FallbackModelIDs are the chat models the answer is retried on, in
+	// order, when the chat model is rate limited, times out or fails with a
+	// server error
+	FallbackModelIDs []string `json:"fallback_model_ids,omitempty"`
+}
+
+// Validate checks the ranges of the set fields.
+func (c *PipelineConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if r := c.Retrieval; r != nil {
+		if r.TopK < 0 || r.TopK > MaxPipelineEmbeddingTopK {
+			return fmt.Errorf("retrieval top_k must be between 0 and %d", MaxPipelineEmbeddingTopK)
+		}
+		if r.VectorThreshold != nil && (*r.VectorThreshold < 0 || *r.VectorThreshold > 1) {
+			return fmt.Errorf("retrieval vector_threshold must be between 0 and 1")
+		}
+		if r.KeywordThreshold != nil && (*r.KeywordThreshold < 0 || *r.KeywordThreshold > 1) {
+			return fmt.Errorf("retrieval keyword_threshold must be between 0 and 1")
+		}
+	}
+	if r := c.Rerank; r != nil {
+		if r.TopK < 0 || r.TopK > MaxRerankTopN {
+			return fmt.Errorf("rerank top_k must be between 0 and %d", MaxRerankTopN)
+		}
+		if r.Threshold != nil && (*r.Threshold < -10 || *r.Threshold > 10) {
+			return fmt.Errorf("rerank threshold must be between -10 and 10")
+		}
+	}
+	if h := c.History; h != nil && h.MaxRounds != nil {
+		if *h.MaxRounds < 0 || *h.MaxRounds > MaxPipelineHistoryRounds {
+			return fmt.Errorf("history max_rounds must be between 0 and %d", MaxPipelineHistoryRounds)
+		}
+	}
+	if g := c.Generation; g != nil {
+		if len(g.FallbackModelIDs) > MaxChatFallbackModels {
+			return fmt.Errorf("generation fallback_model_ids accepts at most %d models", MaxChatFallbackModels)
+		}
+		for _, id := range g.FallbackModelIDs {
+			if strings.TrimSpace(id) == "" {
+				return fmt.Errorf("generation fallback_model_ids must not contain empty IDs")
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyTo overrides the pipeline request with the set fields.
+func (c *PipelineConfig) ApplyTo(r *PipelineRequest) {
+	if c == nil {
+		return
+	}
+	if rw := c.Rewrite; rw != nil {
+		if rw.Enabled != nil {
+			r.EnableRewrite = *rw.Enabled
+		}
+		if rw.QueryExpansion != nil {
+			r.EnableQueryExpansion = *rw.QueryExpansion
+		}
+	}
+	if rt := c.Retrieval; rt != nil {
+		if rt.TopK > 0 {
+			r.EmbeddingTopK = rt.TopK
+		}
+		if rt.VectorThreshold != nil {
+			r.VectorThreshold = *rt.VectorThreshold
+		}
+		if rt.KeywordThreshold != nil {
+			r.KeywordThreshold = *rt.KeywordThreshold
+		}
+	}
+	if rr := c.Rerank; rr != nil {
+		if rr.Enabled != nil && !*rr.Enabled {
+			r.RerankModelID = ""
+		}
+		if rr.TopK > 0 {
+			r.RerankTopK = rr.TopK
+		}
+		if rr.Threshold != nil {
+			r.RerankThreshold = *rr.Threshold
+		}
+	}
+	if m := c.Memory; m != nil && m.Enabled != nil {
+		r.EnableMemory = *m.Enabled
+	}
+	if h := c.History; h != nil && h.MaxRounds != nil {
+		r.MaxRounds = *h.MaxRounds
+	}
+	if g := c.Generation; g != nil && g.FallbackModelIDs != nil {
+		r.ChatFallbackModelIDs = append([]string(nil), g.FallbackModelIDs...)
+	}
+}
+
+// EffectivePipelineConfig returns the stage settings a pipeline request
+// runs with, every field set, for logging and auditing.
+func EffectivePipelineConfig(r *PipelineRequest) *PipelineConfig {
+	rerankEnabled := r.RerankModelID != ""
+	return &PipelineConfig{
+		Rewrite: &PipelineRewriteConfig{
+			Enabled:        &r.EnableRewrite,
+			QueryExpansion: &r.EnableQueryExpansion,
+		},
+		Retrieval: &PipelineRetrievalConfig{
+			TopK:             r.EmbeddingTopK,
+			VectorThreshold:  &r.VectorThreshold,
+			KeywordThreshold: &r.KeywordThreshold,
+		},
+		Rerank: &PipelineRerankConfig{
+			Enabled:   &rerankEnabled,
+			TopK:      r.RerankTopK,
+			Threshold: &r.RerankThreshold,
+		},
+		Memory:     &PipelineMemoryConfig{Enabled: &r.EnableMemory},
+		History:    &PipelineHistoryConfig{MaxRounds: &r.MaxRounds},
+		Generation: &PipelineGenerationConfig{FallbackModelIDs: r.ChatFallbackModelIDs},
+	}
+}
+
+// Value implements the driver.Valuer interface for database serialization
+func (c PipelineConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (c *PipelineConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
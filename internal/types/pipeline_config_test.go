@@ -0,0 +1,91 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipelineConfig_Validate(t *testing.T) {
+	var nilCfg *PipelineConfig
+	assert.NoError(t, nilCfg.Validate())
+
+	threshold, rounds := 0.7, 5
+	valid := &PipelineConfig{
+		Retrieval: &PipelineRetrievalConfig{TopK: 30, VectorThreshold: &threshold},
+		Rerank:    &PipelineRerankConfig{TopK: 10},
+		History:   &PipelineHistoryConfig{MaxRounds: &rounds},
+	}
+	assert.NoError(t, valid.Validate())
+
+	tooHigh, negative, tooManyRounds := 1.5, -11.0, 51
+	for name, cfg := range map[string]*PipelineConfig{
+		"retrieval top_k":   {Retrieval: &PipelineRetrievalConfig{TopK: 201}},
+		"vector threshold":  {Retrieval: &PipelineRetrievalConfig{VectorThreshold: &tooHigh}},
+		"keyword threshold": {Retrieval: &PipelineRetrievalConfig{KeywordThreshold: &tooHigh}},
+		"rerank top_k":      {Rerank: &PipelineRerankConfig{TopK: MaxRerankTopN + 1}},
+		"rerank threshold":  {Rerank: &PipelineRerankConfig{Threshold: &negative}},
+		"history rounds":    {History: &PipelineHistoryConfig{MaxRounds: &tooManyRounds}},
+		"fallback models":   {Generation: &PipelineGenerationConfig{FallbackModelIDs: []string{"a", "b", "c", "d", "e", "f"}}},
+		"blank fallback":    {Generation: &PipelineGenerationConfig{FallbackModelIDs: []string{" "}}},
+	} {
+		assert.Error(t, cfg.Validate(), name)
+	}
+}
+
+func TestPipelineConfig_ApplyTo(t *testing.T) {
+	req := PipelineRequest{
+		EnableRewrite:   true,
+		EnableMemory:    true,
+		EmbeddingTopK:   10,
+		VectorThreshold: 0.5,
+		RerankModelID:   "rerank",
+		RerankTopK:      5,
+		MaxRounds:       5,
+	}
+	var nilCfg *PipelineConfig
+	nilCfg.ApplyTo(&req)
+	assert.Equal(t, "rerank", req.RerankModelID)
+
+	off, threshold, rounds := false, 0.3, 0
+	cfg := &PipelineConfig{
+		Rewrite:    &PipelineRewriteConfig{Enabled: &off},
+		Retrieval:  &PipelineRetrievalConfig{TopK: 40, VectorThreshold: &threshold},
+		Rerank:     &PipelineRerankConfig{Enabled: &off},
+		Memory:     &PipelineMemoryConfig{Enabled: &off},
+		History:    &PipelineHistoryConfig{MaxRounds: &rounds},
+		Generation: &PipelineGenerationConfig{FallbackModelIDs: []string{"backup"}},
+	}
+	cfg.ApplyTo(&req)
+	assert.False(t, req.EnableRewrite)
+	assert.False(t, req.EnableMemory)
+	assert.Equal(t, 40, req.EmbeddingTopK)
+	assert.Equal(t, 0.3, req.VectorThreshold)
+	assert.Empty(t, req.RerankModelID)
+	assert.Equal(t, 5, req.RerankTopK, "unset fields are kept")
+	assert.Equal(t, 0, req.MaxRounds)
+	assert.Equal(t, []string{"backup"}, req.ChatFallbackModelIDs)
+
+	effective := EffectivePipelineConfig(&req)
+	assert.False(t, *effective.Rerank.Enabled)
+	assert.Equal(t, 40, effective.Retrieval.TopK)
+	assert.Equal(t, 0, *effective.History.MaxRounds)
+	assert.Equal(t, []string{"backup"}, effective.Generation.FallbackModelIDs)
+}
+
+func TestPipelineConfig_ValueScan(t *testing.T) {
+	on := true
+	cfg := PipelineConfig{Memory: &PipelineMemoryConfig{Enabled: &on}}
+	v, err := cfg.Value()
+	require.NoError(t, err)
+
+	var got PipelineConfig
+	require.NoError(t, got.Scan(v))
+	assert.Equal(t, cfg, got)
+
+	raw, err := json.Marshal(PipelineConfig{})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(raw))
+}
@@ -0,0 +1,59 @@
+package types
+
+// PipelineTrace records what each stage of one knowledge QA run read and
+// produced. It is only built when the caller asked for a debug trace, and
+// is streamed to the caller before the answer.
+type PipelineTrace struct {
+	// Query is the question as asked
+	Query string `json:"query"`
+	// Config is the effective stage configuration of the run
+	Config           *PipelineConfig `json:"config"`
+	KnowledgeBaseIDs []string        `json:"knowledge_base_ids,omitempty"`
+	KnowledgeIDs     []string        `json:"knowledge_ids,omitempty"`
+	ChatModelID      string          `json:"chat_model_id,omitempty"`
+	RerankModelID    string          `json:"rerank_model_id,omitempty"`
+	// Stages are the pipeline stages run so far, in order
+	Stages []PipelineStageTrace `json:"stages"`
+	// Exclusions are the retrieval candidates dropped along the way
+	Exclusions []ChunkExclusion `json:"exclusions"`
+	// Prompt is the final prompt sent to the answering model; empty when
+	// the run ended before it was built
+	Prompt []PipelineTraceMessage `json:"prompt,omitempty"`
+}
+
+// PipelineStageTrace records one pipeline stage
+type PipelineStageTrace struct {
+	Stage      EventType `json:"stage"`
+	DurationMs int64     `json:"duration_ms"`
+	// Error is the stage's error type, e.g. search_nothing
+	Error string `json:"error,omitempty"`
+	// Output is the state the stage produced, such as the rewritten query
+	// or the ranked results; its keys depend on the stage
+	Output map[string]interface{} `json:"output,omitempty"`
+}
+
+// PipelineTraceResult is a retrieval result as listed in a stage trace
+type PipelineTraceResult struct {
+	ChunkID        string    `json:"chunk_id"`
+	KnowledgeID    string    `json:"knowledge_id"`
+	KnowledgeTitle string    `json:"knowledge_title,omitempty"`
+	Score          float64   `json:"score"`
+	MatchType      MatchType `json:"match_type"`
+	// Content is the start of the chunk content
+	Content string `json:"content"`
+}
+
+// PipelineTraceMessage is one message of the traced prompt
+type PipelineTraceMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// AddStage appends a stage record. A nil trace drops it, so callers need
+// not check whether tracing is on.
+func (t *PipelineTrace) AddStage(stage PipelineStageTrace) {
+	if t == nil {
+		return
+	}
+	t.Stages = append(t.Stages, stage)
+}
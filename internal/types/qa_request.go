@@ -19,6 +19,8 @@ type QARequest struct {
 	UserMessageID      string             // Created user message ID
 	WebSearchEnabled   bool               // Whether web search is enabled for this request
 	EnableMemory       bool               // Whether memory feature is enabled
+	Remember           bool               // Store this turn in memory whatever the session's memory policy says
+	Debug              bool               // Stream a per-stage pipeline trace before the answer (knowledge QA only)
 	QuotedContext      string             // Quoted message content from IM quote-reply (appended at LLM prompt stage, not used for retrieval)
 	Attachments        MessageAttachments // File attachments (processed and ready for prompt injection)
 }
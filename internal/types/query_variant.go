@@ -0,0 +1,59 @@
+package types
+
+// QueryVariantType is the way a query variant reformulates the question.
+type QueryVariantType string
+
+const (
+	// QueryVariantParaphrase rewords the question with other terms.
+	QueryVariantParaphrase QueryVariantType = "paraphrase"
+	// QueryVariantSubQuestion is one part of a question asking several things.
+	QueryVariantSubQuestion QueryVariantType = "sub_question"
+	// QueryVariantHyDE is a hypothetical answer passage, which tends to sit
+	// closer to the answering chunks than the question itself.
+	QueryVariantHyDE QueryVariantType = "hyde"
+)
+
+const (
+	// DefaultMultiQueryCount is the number of variants generated when the
+	// agent does not set one.
+	DefaultMultiQueryCount = 3
+	// MaxMultiQueryCount caps the variants generated per question.
+	MaxMultiQueryCount = 5
+)
+
+// QueryVariant is an alternative formulation of the question that is
+// retrieved alongside it in multi-query mode.
+type QueryVariant struct {
+	Type  QueryVariantType `json:"type"`
+	Query string           `json:"query"`
+}
+
+// EffectiveMultiQueryStrategies returns the known variant types among
+// strategies, in order and without repeats, or all of them when none is
+// given or known.
+func EffectiveMultiQueryStrategies(strategies []string) []QueryVariantType {
+	var out []QueryVariantType
+	seen := make(map[QueryVariantType]bool)
+	for _, s := range strategies {
+		t := QueryVariantType(s)
+		switch t {
+		case QueryVariantParaphrase, QueryVariantSubQuestion, QueryVariantHyDE:
+			if !seen[t] {
+				seen[t] = true
+				out = append(out, t)
+			}
+		}
+	}
+	if len(out) == 0 {
+		return []QueryVariantType{QueryVariantParaphrase, QueryVariantSubQuestion, QueryVariantHyDE}
+	}
+	return out
+}
+
+// EffectiveMultiQueryCount clamps the number of variants to generate.
+func EffectiveMultiQueryCount(count int) int {
+	if count <= 0 {
+		return DefaultMultiQueryCount
+	}
+	return min(count, MaxMultiQueryCount)
+}
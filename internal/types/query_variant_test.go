@@ -0,0 +1,17 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEffectiveMultiQuerySettings(t *testing.T) {
+	assert.Equal(t, DefaultMultiQueryCount, EffectiveMultiQueryCount(0))
+	assert.Equal(t, 2, EffectiveMultiQueryCount(2))
+	assert.Equal(t, MaxMultiQueryCount, EffectiveMultiQueryCount(50))
+	assert.Equal(t, []QueryVariantType{QueryVariantHyDE, QueryVariantParaphrase},
+		EffectiveMultiQueryStrategies([]string{"hyde", "bogus", "paraphrase", "hyde"}))
+	assert.Len(t, EffectiveMultiQueryStrategies([]string{"bogus"}), 3)
+	assert.Len(t, EffectiveMultiQueryStrategies(nil), 3)
+}
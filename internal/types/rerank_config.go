@@ -0,0 +1,59 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// MaxRerankTopN bounds the per-session rerank top-N.
+const MaxRerankTopN = 100
+
+// SessionRerankConfig is the per-session setting of the rerank stage of the
+// knowledge QA pipeline. A nil config keeps the agent's settings.
+type SessionRerankConfig struct {
+	// Enabled turns reranking on or off for the session; nil keeps the
+	// agent's setting. Without reranking the retrieval scores are used.
+	Enabled *bool `json:"enabled,omitempty"`
+	// TopN overrides the number of reranked results kept; 0 keeps the
+	// agent's rerank_top_k.
+	TopN int `json:"top_n,omitempty"`
+}
+
+// Validate checks the top-N.
+func (c *SessionRerankConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.TopN < 0 || c.TopN > MaxRerankTopN {
+		return fmt.Errorf("rerank top_n must be between 0 and %d", MaxRerankTopN)
+	}
+	return nil
+}
+
+// Disabled reports whether the session turns reranking off.
+func (c *SessionRerankConfig) Disabled() bool {
+	return c != nil && c.Enabled != nil && !*c.Enabled
+}
+
+// Value implements the driver.Valuer interface for database serialization
+func (c SessionRerankConfig) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (c *SessionRerankConfig) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(b, c)
+}
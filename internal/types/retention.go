@@ -0,0 +1,111 @@
+package types
+
+import "time"
+
+// Retention limits.
+const (
+	// MaxRetentionDays bounds every retention period (about ten years)
+	MaxRetentionDays = 3650
+	// RetentionRunInterval is the gap between runs of an enabled policy
+	RetentionRunInterval = 24 * time.Hour
+)
+
+// RetentionPolicy deletes a tenant's data once it is older than configured.
+// A tenant has one tenant-wide policy (KnowledgeBaseID empty) covering chat
+// sessions, messages and memory episodes, and at most one policy per
+// knowledge base covering its documents. A zero period keeps that kind of
+// data forever.
+type RetentionPolicy struct {
+	ID       string `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	TenantID uint64 `json:"tenant_id"         gorm:"uniqueIndex:idx_retention_policies_scope"`
+	// KnowledgeBaseID is empty for the tenant-wide policy
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);uniqueIndex:idx_retention_policies_scope"`
+	Enabled         bool   `json:"enabled"`
+	// SessionRetentionDays deletes sessions idle for longer, with their
+	// messages, and older messages of active sessions. Tenant-wide only.
+	SessionRetentionDays int `json:"session_retention_days"`
+	// MemoryRetentionDays deletes older memory episodes. Tenant-wide only.
+	MemoryRetentionDays int `json:"memory_retention_days"`
+	// KnowledgeRetentionDays deletes documents created longer ago.
+	// Knowledge base policies only.
+	KnowledgeRetentionDays int `json:"knowledge_retention_days"`
+	// KnowledgeExpiresAt expires the documents created before it once it
+	// has passed. Knowledge base policies only.
+	KnowledgeExpiresAt *time.Time `json:"knowledge_expires_at"`
+	// NextRunAt is when the policy next runs; nil while it is disabled
+	NextRunAt *time.Time `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name of RetentionPolicy
+func (RetentionPolicy) TableName() string {
+	return "retention_policies"
+}
+
+// IsTenantWide reports whether the policy is the tenant-wide one.
+func (p *RetentionPolicy) IsTenantWide() bool {
+	return p.KnowledgeBaseID == ""
+}
+
+// SessionCutoff returns the time before which sessions and messages are
+// deleted, or nil when they are kept.
+func (p *RetentionPolicy) SessionCutoff(now time.Time) *time.Time {
+	return retentionCutoff(now, p.SessionRetentionDays)
+}
+
+// MemoryCutoff returns the time before which memory episodes are deleted,
+// or nil when they are kept.
+func (p *RetentionPolicy) MemoryCutoff(now time.Time) *time.Time {
+	return retentionCutoff(now, p.MemoryRetentionDays)
+}
+
+// KnowledgeCutoff returns the time before which documents were created to
+// be deleted, or nil when they are kept. It is the later of the retention
+// period and an expiry date that has passed.
+func (p *RetentionPolicy) KnowledgeCutoff(now time.Time) *time.Time {
+	cutoff := retentionCutoff(now, p.KnowledgeRetentionDays)
+	if p.KnowledgeExpiresAt != nil && !now.Before(*p.KnowledgeExpiresAt) {
+		if cutoff == nil || p.KnowledgeExpiresAt.After(*cutoff) {
+			expiresAt := *p.KnowledgeExpiresAt
+			cutoff = &expiresAt
+		}
+	}
+	return cutoff
+}
+
+func retentionCutoff(now time.Time, days int) *time.Time {
+	if days <= 0 {
+		return nil
+	}
+	cutoff := now.AddDate(0, 0, -days)
+	return &cutoff
+}
+
+// RetentionPolicyRequest replaces the settings of a retention policy.
+// Fields that do not apply to the policy's scope must be left empty.
+type RetentionPolicyRequest struct {
+	Enabled                bool       `json:"enabled"`
+	SessionRetentionDays   int        `json:"session_retention_days"`
+	MemoryRetentionDays    int        `json:"memory_retention_days"`
+	KnowledgeRetentionDays int        `json:"knowledge_retention_days"`
+	KnowledgeExpiresAt     *time.Time `json:"knowledge_expires_at"`
+}
+
+// RetentionReport tells what a retention run deleted, or for a dry run
+// what it would delete.
+type RetentionReport struct {
+	PolicyID        string `json:"policy_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id,omitempty"`
+	Enabled         bool   `json:"enabled"`
+	DryRun          bool   `json:"dry_run"`
+	// Cutoffs are nil for the kinds of data the policy keeps
+	SessionCutoff   *time.Time `json:"session_cutoff,omitempty"`
+	MemoryCutoff    *time.Time `json:"memory_cutoff,omitempty"`
+	KnowledgeCutoff *time.Time `json:"knowledge_cutoff,omitempty"`
+	Sessions        int64      `json:"sessions"`
+	Messages        int64      `json:"messages"`
+	Episodes        int64      `json:"episodes"`
+	Knowledge       int64      `json:"knowledge"`
+}
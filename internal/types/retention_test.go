@@ -0,0 +1,40 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetentionPolicyCutoffs(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	empty := &RetentionPolicy{}
+	assert.True(t, empty.IsTenantWide())
+	assert.Nil(t, empty.SessionCutoff(now), "a zero period keeps data forever")
+	assert.Nil(t, empty.MemoryCutoff(now))
+	assert.Nil(t, empty.KnowledgeCutoff(now))
+
+	tenant := &RetentionPolicy{SessionRetentionDays: 30, MemoryRetentionDays: 365}
+	require.NotNil(t, tenant.SessionCutoff(now))
+	assert.Equal(t, now.AddDate(0, 0, -30), *tenant.SessionCutoff(now))
+	assert.Equal(t, now.AddDate(0, 0, -365), *tenant.MemoryCutoff(now))
+
+	future := now.Add(time.Hour)
+	kb := &RetentionPolicy{KnowledgeBaseID: "kb1", KnowledgeExpiresAt: &future}
+	assert.False(t, kb.IsTenantWide())
+	assert.Nil(t, kb.KnowledgeCutoff(now), "an expiry date in the future expires nothing yet")
+
+	kb.KnowledgeRetentionDays = 90
+	assert.Equal(t, now.AddDate(0, 0, -90), *kb.KnowledgeCutoff(now))
+
+	past := now.AddDate(0, 0, -10)
+	kb.KnowledgeExpiresAt = &past
+	assert.Equal(t, past, *kb.KnowledgeCutoff(now), "a passed expiry date later than the period wins")
+
+	longAgo := now.AddDate(-1, 0, 0)
+	kb.KnowledgeExpiresAt = &longAgo
+	assert.Equal(t, now.AddDate(0, 0, -90), *kb.KnowledgeCutoff(now))
+}
@@ -35,6 +35,21 @@ type RetrievalConfig struct {
 	RRFVectorWeight float64 `json:"rrf_vector_weight,omitempty"`
 	// RRFKeywordWeight is the keyword counterpart. Default: 0.3.
 	RRFKeywordWeight float64 `json:"rrf_keyword_weight,omitempty"`
+
+	// DegradationPolicy decides what happens when a vector store is
+	// unreachable during search: "fail" (default), "keyword_fallback" or
+	// "unavailable". See RetrievalDegradationPolicy.
+	DegradationPolicy RetrievalDegradationPolicy `json:"degradation_policy,omitempty"`
+}
+
+// GetEffectiveDegradationPolicy returns DegradationPolicy with a fallback
+// default. Unknown values are treated as "fail" so a typo never silently
+// hides engine outages.
+func (c *RetrievalConfig) GetEffectiveDegradationPolicy() RetrievalDegradationPolicy {
+	if c == nil || !c.DegradationPolicy.IsValid() {
+		return RetrievalDegradationFail
+	}
+	return c.DegradationPolicy
 }
 
 // GetEffectiveEmbeddingTopK returns EmbeddingTopK with a fallback default.
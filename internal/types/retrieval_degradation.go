@@ -0,0 +1,99 @@
+package types
+
+import (
+	"context"
+	"sync"
+)
+
+// RetrievalDegradationPolicy controls how HybridSearch reacts when a vector
+// store (or any engine in a store group) fails mid-request.
+type RetrievalDegradationPolicy string
+
+const (
+	// RetrievalDegradationFail keeps the historical all-or-nothing contract:
+	// the first engine failure aborts the search with a typed error.
+	RetrievalDegradationFail RetrievalDegradationPolicy = "fail"
+	// RetrievalDegradationKeywordFallback retries the failed search with the
+	// keyword retrievers only. When the keyword path is served by a different
+	// engine (e.g. Postgres/ES keywords next to a Milvus vector store) the
+	// user still gets lexical hits while the vector store is down.
+	RetrievalDegradationKeywordFallback RetrievalDegradationPolicy = "keyword_fallback"
+	// RetrievalDegradationUnavailable returns an empty result set flagged as
+	// "retrieval unavailable" so the chat layer can answer honestly instead
+	// of surfacing a transport error.
+	RetrievalDegradationUnavailable RetrievalDegradationPolicy = "unavailable"
+)
+
+// IsValid reports whether p is one of the known policies.
+func (p RetrievalDegradationPolicy) IsValid() bool {
+	switch p {
+	case RetrievalDegradationFail, RetrievalDegradationKeywordFallback, RetrievalDegradationUnavailable:
+		return true
+	}
+	return false
+}
+
+// RetrievalStatus describes whether a retrieval was served degraded.
+// The zero value means "healthy".
+type RetrievalStatus struct {
+	// Degraded is true when any search in the request took a degraded path.
+	Degraded bool `json:"degraded"`
+	// Mode is the degradation policy that was applied (keyword_fallback or
+	// unavailable). When several searches degraded differently, the most
+	// severe mode wins.
+	Mode RetrievalDegradationPolicy `json:"mode,omitempty"`
+	// Reason is a short, user-safe explanation. It never carries raw engine
+	// errors or store identifiers.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Unavailable reports whether retrieval was skipped entirely.
+func (s RetrievalStatus) Unavailable() bool {
+	return s.Degraded && s.Mode == RetrievalDegradationUnavailable
+}
+
+// RetrievalStatusRecorder collects degradation decisions made by concurrent
+// HybridSearch calls belonging to one request (chat turn, agent tool call or
+// HTTP search). It is safe for concurrent use.
+type RetrievalStatusRecorder struct {
+	mu     sync.Mutex
+	status RetrievalStatus
+}
+
+// Record marks the request as degraded with the given mode. An
+// "unavailable" record is never downgraded by a later keyword fallback.
+func (r *RetrievalStatusRecorder) Record(mode RetrievalDegradationPolicy, reason string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status.Mode == RetrievalDegradationUnavailable && mode != RetrievalDegradationUnavailable {
+		return
+	}
+	r.status = RetrievalStatus{Degraded: true, Mode: mode, Reason: reason}
+}
+
+// Status returns a snapshot of the recorded status.
+func (r *RetrievalStatusRecorder) Status() RetrievalStatus {
+	if r == nil {
+		return RetrievalStatus{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// WithRetrievalStatusRecorder attaches a fresh recorder to ctx. Callers read
+// the outcome from the returned recorder once their searches complete.
+func WithRetrievalStatusRecorder(ctx context.Context) (context.Context, *RetrievalStatusRecorder) {
+	rec := &RetrievalStatusRecorder{}
+	return context.WithValue(ctx, RetrievalStatusContextKey, rec), rec
+}
+
+// RetrievalStatusRecorderFromContext returns the recorder attached to ctx,
+// or nil when none is present. A nil recorder silently drops records.
+func RetrievalStatusRecorderFromContext(ctx context.Context) *RetrievalStatusRecorder {
+	rec, _ := ctx.Value(RetrievalStatusContextKey).(*RetrievalStatusRecorder)
+	return rec
+}
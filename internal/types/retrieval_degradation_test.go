@@ -0,0 +1,45 @@
+package types
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRetrievalConfigDegradationPolicyDefaults(t *testing.T) {
+	var nilCfg *RetrievalConfig
+	if got := nilCfg.GetEffectiveDegradationPolicy(); got != RetrievalDegradationFail {
+		t.Fatalf("nil config: got %q, want fail", got)
+	}
+	if got := (&RetrievalConfig{DegradationPolicy: "bogus"}).GetEffectiveDegradationPolicy(); got != RetrievalDegradationFail {
+		t.Fatalf("unknown policy: got %q, want fail", got)
+	}
+	cfg := &RetrievalConfig{DegradationPolicy: RetrievalDegradationKeywordFallback}
+	if got := cfg.GetEffectiveDegradationPolicy(); got != RetrievalDegradationKeywordFallback {
+		t.Fatalf("got %q, want keyword_fallback", got)
+	}
+}
+
+func TestRetrievalStatusRecorder(t *testing.T) {
+	if RetrievalStatusRecorderFromContext(context.Background()) != nil {
+		t.Fatal("expected no recorder on a bare context")
+	}
+	// A nil recorder must swallow records so HybridSearch callers that do
+	// not care about status need no setup.
+	var nilRec *RetrievalStatusRecorder
+	nilRec.Record(RetrievalDegradationUnavailable, "x")
+
+	ctx, rec := WithRetrievalStatusRecorder(context.Background())
+	if RetrievalStatusRecorderFromContext(ctx) != rec {
+		t.Fatal("recorder not attached to context")
+	}
+	if rec.Status().Degraded {
+		t.Fatal("fresh recorder must report healthy")
+	}
+
+	rec.Record(RetrievalDegradationUnavailable, "down")
+	rec.Record(RetrievalDegradationKeywordFallback, "partial")
+	status := rec.Status()
+	if !status.Unavailable() || status.Reason != "down" {
+		t.Fatalf("unavailable must not be downgraded, got %+v", status)
+	}
+}
@@ -0,0 +1,542 @@
+package types
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Retrieval evaluation limits.
+const (
+	// MaxEvalDatasetItems bounds the questions of one golden dataset
+	MaxEvalDatasetItems = 1000
+	// MaxEvalExpectedSources bounds the expected sources of one question
+	MaxEvalExpectedSources = 20
+	// DefaultEvalTopK is the rank cutoff of the retrieval metrics when a
+	// run does not set one
+	DefaultEvalTopK = 10
+	// MaxEvalTopK bounds the rank cutoff of the retrieval metrics
+	MaxEvalTopK = 100
+)
+
+// EvalRunMode selects how much of the RAG pipeline an evaluation run executes.
+type EvalRunMode string
+
+const (
+	// EvalRunModeRetrieval runs search and rerank only and scores the ranking
+	EvalRunModeRetrieval EvalRunMode = "retrieval"
+	// EvalRunModeRAG also generates answers and has a judge model grade them
+	EvalRunModeRAG EvalRunMode = "rag"
+)
+
+// EvalRunStatus is the state of an evaluation run.
+type EvalRunStatus string
+
+const (
+	EvalRunStatusPending   EvalRunStatus = "pending"
+	EvalRunStatusRunning   EvalRunStatus = "running"
+	EvalRunStatusCompleted EvalRunStatus = "completed"
+	EvalRunStatusFailed    EvalRunStatus = "failed"
+)
+
+// EvalDataset is a golden dataset of a tenant: questions with the sources
+// that should be retrieved for them and, optionally, reference answers.
+type EvalDataset struct {
+	ID          string    `json:"id"          gorm:"type:varchar(36);primaryKey"`
+	TenantID    uint64    `json:"tenant_id"   gorm:"index"`
+	Name        string    `json:"name"        gorm:"type:varchar(255)"`
+	Description string    `json:"description" gorm:"type:text"`
+	ItemCount   int       `json:"item_count"`
+	CreatedBy   string    `json:"created_by"  gorm:"type:varchar(36)"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// Items is only filled when a single dataset is fetched
+	Items []*EvalDatasetItem `json:"items,omitempty" gorm:"-"`
+}
+
+// TableName returns the table name of EvalDataset
+func (EvalDataset) TableName() string {
+	return "eval_datasets"
+}
+
+// EvalDatasetItem is one question of a golden dataset.
+type EvalDatasetItem struct {
+	ID        string `json:"id"         gorm:"type:varchar(36);primaryKey"`
+	DatasetID string `json:"dataset_id" gorm:"type:varchar(36);index"`
+	// Position keeps the upload order of the questions
+	Position       int    `json:"position"`
+	Question       string `json:"question"        gorm:"type:text"`
+	ExpectedAnswer string `json:"expected_answer" gorm:"type:text"`
+	// ExpectedSources are the sources the retrieval should return; an item
+	// without any only contributes to the answer metrics
+	ExpectedSources EvalExpectedSources `json:"expected_sources" gorm:"type:json"`
+}
+
+// TableName returns the table name of EvalDatasetItem
+func (EvalDatasetItem) TableName() string {
+	return "eval_dataset_items"
+}
+
+// EvalExpectedSource identifies a source expected in the retrieval results.
+// Set exactly one field. A chunk ID matches that chunk only; a knowledge ID
+// or a document title or file name matches any chunk of the document, so
+// datasets written against document names stay valid when the documents are
+// re-chunked or imported into another knowledge base.
+type EvalExpectedSource struct {
+	ChunkID     string `json:"chunk_id,omitempty"`
+	KnowledgeID string `json:"knowledge_id,omitempty"`
+	Title       string `json:"title,omitempty"`
+}
+
+// Matches reports whether a search result is this source.
+func (s *EvalExpectedSource) Matches(result *SearchResult) bool {
+	switch {
+	case s.ChunkID != "":
+		return result.ID == s.ChunkID || result.ParentChunkID == s.ChunkID
+	case s.KnowledgeID != "":
+		return result.KnowledgeID == s.KnowledgeID
+	case s.Title != "":
+		title := strings.TrimSpace(s.Title)
+		return strings.EqualFold(strings.TrimSpace(result.KnowledgeTitle), title) ||
+			strings.EqualFold(strings.TrimSpace(result.KnowledgeFilename), title)
+	}
+	return false
+}
+
+func (s *EvalExpectedSource) validate() error {
+	set := 0
+	for _, v := range []string{s.ChunkID, s.KnowledgeID, strings.TrimSpace(s.Title)} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("expected source must set exactly one of chunk_id, knowledge_id and title")
+	}
+	return nil
+}
+
+// EvalExpectedSources is the expected sources of a question
+type EvalExpectedSources []EvalExpectedSource
+
+// Value implements the driver.Valuer interface
+func (s EvalExpectedSources) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(s)
+	return string(b), err
+}
+
+// Scan implements the sql.Scanner interface
+func (s *EvalExpectedSources) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	}
+	return nil
+}
+
+// MatchRanking maps a ranked result list onto the expected sources:
+// matches[i] is the index of the source result i is, or -1. Each source is
+// counted at its first match only, so further chunks of an expected
+// document do not inflate the metrics.
+func (s EvalExpectedSources) MatchRanking(results []*SearchResult) []int {
+	matches := make([]int, len(results))
+	found := make([]bool, len(s))
+	for i, result := range results {
+		matches[i] = -1
+		for j := range s {
+			if !found[j] && s[j].Matches(result) {
+				found[j] = true
+				matches[i] = j
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// EvalDatasetItemRequest is one question of an uploaded dataset.
+type EvalDatasetItemRequest struct {
+	Question        string               `json:"question"`
+	ExpectedAnswer  string               `json:"expected_answer"`
+	ExpectedSources []EvalExpectedSource `json:"expected_sources"`
+}
+
+// EvalDatasetRequest creates a golden dataset.
+type EvalDatasetRequest struct {
+	Name        string                    `json:"name"`
+	Description string                    `json:"description"`
+	Items       []*EvalDatasetItemRequest `json:"items"`
+}
+
+// Validate checks the dataset and trims its questions.
+func (r *EvalDatasetRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(r.Items) == 0 {
+		return errors.New("dataset has no items")
+	}
+	if len(r.Items) > MaxEvalDatasetItems {
+		return fmt.Errorf("dataset has %d items, at most %d are allowed", len(r.Items), MaxEvalDatasetItems)
+	}
+	for i, item := range r.Items {
+		if item == nil {
+			return fmt.Errorf("item %d is empty", i+1)
+		}
+		item.Question = strings.TrimSpace(item.Question)
+		if item.Question == "" {
+			return fmt.Errorf("item %d has no question", i+1)
+		}
+		if len(item.ExpectedSources) == 0 && strings.TrimSpace(item.ExpectedAnswer) == "" {
+			return fmt.Errorf("item %d needs expected sources or an expected answer", i+1)
+		}
+		if len(item.ExpectedSources) > MaxEvalExpectedSources {
+			return fmt.Errorf("item %d has more than %d expected sources", i+1, MaxEvalExpectedSources)
+		}
+		for j := range item.ExpectedSources {
+			if err := item.ExpectedSources[j].validate(); err != nil {
+				return fmt.Errorf("item %d: %w", i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ParseEvalDatasetItems parses an uploaded dataset file: a JSON array of
+// items or JSON Lines with one item per line. Blank lines are skipped.
+func ParseEvalDatasetItems(data []byte) ([]*EvalDatasetItemRequest, error) {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	var items []*EvalDatasetItemRequest
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, fmt.Errorf("parse JSON array: %w", err)
+		}
+		return items, nil
+	}
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var item EvalDatasetItemRequest
+		if err := json.Unmarshal(line, &item); err != nil {
+			return nil, fmt.Errorf("parse line %d: %w", i+1, err)
+		}
+		items = append(items, &item)
+	}
+	return items, nil
+}
+
+// EvalRunConfig is the retrieval configuration an evaluation run tests.
+// Runs of the same dataset with different configurations are compared to
+// A/B test a change. Unset fields take the conversation defaults.
+type EvalRunConfig struct {
+	Mode EvalRunMode `json:"mode"`
+	// TopK is the rank cutoff of recall@k, nDCG@k and hit rate
+	TopK             int      `json:"top_k"`
+	EmbeddingTopK    int      `json:"embedding_top_k"`
+	VectorThreshold  *float64 `json:"vector_threshold"`
+	KeywordThreshold *float64 `json:"keyword_threshold"`
+	// RerankModelID empty scores the search results without reranking
+	RerankModelID   string   `json:"rerank_model_id"`
+	RerankTopK      int      `json:"rerank_top_k"`
+	RerankThreshold *float64 `json:"rerank_threshold"`
+	// ChatModelID answers the questions in rag mode; defaults to the
+	// knowledge base's summary model
+	ChatModelID string `json:"chat_model_id"`
+	// JudgeModelID grades the answers in rag mode; defaults to ChatModelID
+	JudgeModelID string `json:"judge_model_id"`
+	// ChunkingConfig records how the knowledge base was chunked when the
+	// run started; it is filled by the run, not the caller
+	ChunkingConfig *ChunkingConfig `json:"chunking_config,omitempty"`
+}
+
+// Value implements the driver.Valuer interface
+func (c EvalRunConfig) Value() (driver.Value, error) {
+	b, err := json.Marshal(c)
+	return string(b), err
+}
+
+// Scan implements the sql.Scanner interface
+func (c *EvalRunConfig) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
+	}
+	return nil
+}
+
+// Validate checks the configuration and fills the mode and rank cutoff.
+func (c *EvalRunConfig) Validate() error {
+	switch c.Mode {
+	case "":
+		c.Mode = EvalRunModeRetrieval
+	case EvalRunModeRetrieval, EvalRunModeRAG:
+	default:
+		return fmt.Errorf("unknown mode %q", c.Mode)
+	}
+	if c.TopK == 0 {
+		c.TopK = DefaultEvalTopK
+	}
+	if c.TopK < 0 || c.TopK > MaxEvalTopK {
+		return fmt.Errorf("top_k must be between 1 and %d", MaxEvalTopK)
+	}
+	if c.EmbeddingTopK < 0 || c.RerankTopK < 0 {
+		return errors.New("embedding_top_k and rerank_top_k must not be negative")
+	}
+	return nil
+}
+
+// EvalRunRequest starts an evaluation run.
+type EvalRunRequest struct {
+	DatasetID       string        `json:"dataset_id"`
+	KnowledgeBaseID string        `json:"knowledge_base_id"`
+	Name            string        `json:"name"`
+	Config          EvalRunConfig `json:"config"`
+}
+
+// EvalMetrics are the metrics of one question or, averaged, of a run.
+// Retrieval metrics cover the questions with expected sources; the answer
+// metrics are nil outside rag mode and for questions the judge could not
+// grade.
+type EvalMetrics struct {
+	RecallAtK       float64  `json:"recall_at_k"`
+	MRR             float64  `json:"mrr"`
+	NDCGAtK         float64  `json:"ndcg_at_k"`
+	HitRateAtK      float64  `json:"hit_rate_at_k"`
+	Faithfulness    *float64 `json:"faithfulness,omitempty"`
+	AnswerRelevance *float64 `json:"answer_relevance,omitempty"`
+	// AvgLatencyMs is the mean time the pipeline took per question
+	AvgLatencyMs int64 `json:"avg_latency_ms"`
+}
+
+// Value implements the driver.Valuer interface
+func (m EvalMetrics) Value() (driver.Value, error) {
+	b, err := json.Marshal(m)
+	return string(b), err
+}
+
+// Scan implements the sql.Scanner interface
+func (m *EvalMetrics) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	}
+	return nil
+}
+
+// EvalRun is one execution of a golden dataset against a knowledge base.
+type EvalRun struct {
+	ID              string        `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64        `json:"tenant_id"         gorm:"index"`
+	DatasetID       string        `json:"dataset_id"        gorm:"type:varchar(36);index"`
+	KnowledgeBaseID string        `json:"knowledge_base_id" gorm:"type:varchar(36)"`
+	Name            string        `json:"name"              gorm:"type:varchar(255)"`
+	Status          EvalRunStatus `json:"status"            gorm:"type:varchar(32)"`
+	Config          EvalRunConfig `json:"config"            gorm:"type:json"`
+	// Metrics are averaged over the questions once the run completed
+	Metrics  *EvalMetrics `json:"metrics"  gorm:"type:json"`
+	Total    int          `json:"total"`
+	Finished int          `json:"finished"`
+	// Failed counts the questions the pipeline errored on; they are left
+	// out of the averages
+	Failed     int        `json:"failed"`
+	ErrMsg     string     `json:"err_msg"    gorm:"type:text"`
+	CreatedBy  string     `json:"created_by" gorm:"type:varchar(36)"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name of EvalRun
+func (EvalRun) TableName() string {
+	return "eval_runs"
+}
+
+// EvalRetrievedSource is one ranked result of a question.
+type EvalRetrievedSource struct {
+	ChunkID     string  `json:"chunk_id"`
+	KnowledgeID string  `json:"knowledge_id"`
+	Title       string  `json:"title"`
+	Score       float64 `json:"score"`
+	// Expected is the index of the expected source the result matched, or -1
+	Expected int `json:"expected"`
+}
+
+// EvalRetrievedSources is the ranking of a question
+type EvalRetrievedSources []EvalRetrievedSource
+
+// Value implements the driver.Valuer interface
+func (s EvalRetrievedSources) Value() (driver.Value, error) {
+	if s == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(s)
+	return string(b), err
+}
+
+// Scan implements the sql.Scanner interface
+func (s *EvalRetrievedSources) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	}
+	return nil
+}
+
+// EvalRunResult is the outcome of one question in a run.
+type EvalRunResult struct {
+	ID       string `json:"id"       gorm:"type:varchar(36);primaryKey"`
+	RunID    string `json:"run_id"   gorm:"type:varchar(36);index"`
+	ItemID   string `json:"item_id"  gorm:"type:varchar(36)"`
+	Position int    `json:"position"`
+	Question string `json:"question" gorm:"type:text"`
+	// ExpectedSources is how many sources the question expected; questions
+	// without any are left out of the retrieval averages
+	ExpectedSources int `json:"expected_sources"`
+	// Retrieved holds the top k results the metrics were computed on
+	Retrieved EvalRetrievedSources `json:"retrieved" gorm:"type:json"`
+	Metrics   EvalMetrics          `json:"metrics"   gorm:"type:json"`
+	// Answer and JudgeReason are only set in rag mode
+	Answer      string    `json:"answer"       gorm:"type:text"`
+	JudgeReason string    `json:"judge_reason" gorm:"type:text"`
+	ErrMsg      string    `json:"err_msg"      gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName returns the table name of EvalRunResult
+func (EvalRunResult) TableName() string {
+	return "eval_run_results"
+}
+
+// AverageEvalMetrics averages the metrics of the questions of a run.
+// Failed questions are skipped, retrieval metrics cover the questions with
+// expected sources and answer metrics the questions the judge graded.
+func AverageEvalMetrics(results []*EvalRunResult) *EvalMetrics {
+	avg := &EvalMetrics{}
+	var retrieved, timed, faithful, relevant int
+	var faithfulness, relevance float64
+	for _, r := range results {
+		if r.ErrMsg != "" {
+			continue
+		}
+		timed++
+		avg.AvgLatencyMs += r.Metrics.AvgLatencyMs
+		if r.ExpectedSources > 0 {
+			retrieved++
+			avg.RecallAtK += r.Metrics.RecallAtK
+			avg.MRR += r.Metrics.MRR
+			avg.NDCGAtK += r.Metrics.NDCGAtK
+			avg.HitRateAtK += r.Metrics.HitRateAtK
+		}
+		if r.Metrics.Faithfulness != nil {
+			faithful++
+			faithfulness += *r.Metrics.Faithfulness
+		}
+		if r.Metrics.AnswerRelevance != nil {
+			relevant++
+			relevance += *r.Metrics.AnswerRelevance
+		}
+	}
+	if retrieved > 0 {
+		n := float64(retrieved)
+		avg.RecallAtK /= n
+		avg.MRR /= n
+		avg.NDCGAtK /= n
+		avg.HitRateAtK /= n
+	}
+	if timed > 0 {
+		avg.AvgLatencyMs /= int64(timed)
+	}
+	if faithful > 0 {
+		v := faithfulness / float64(faithful)
+		avg.Faithfulness = &v
+	}
+	if relevant > 0 {
+		v := relevance / float64(relevant)
+		avg.AnswerRelevance = &v
+	}
+	return avg
+}
+
+// EvalRunPayload is the payload of the evaluation run task
+type EvalRunPayload struct {
+	TracingContext
+	TenantID uint64 `json:"tenant_id"`
+	RunID    string `json:"run_id"`
+	// UserID is who started the run; retrieval sees the documents whose
+	// ACL lets that user read them
+	UserID   string `json:"user_id,omitempty"`
+	Language string `json:"language,omitempty"`
+}
+
+// EvalMetricsDelta is the difference of a run's metrics from the baseline
+// run. Answer metric deltas are nil unless both runs have them.
+type EvalMetricsDelta struct {
+	RunID           string   `json:"run_id"`
+	RecallAtK       float64  `json:"recall_at_k"`
+	MRR             float64  `json:"mrr"`
+	NDCGAtK         float64  `json:"ndcg_at_k"`
+	HitRateAtK      float64  `json:"hit_rate_at_k"`
+	Faithfulness    *float64 `json:"faithfulness,omitempty"`
+	AnswerRelevance *float64 `json:"answer_relevance,omitempty"`
+	AvgLatencyMs    int64    `json:"avg_latency_ms"`
+}
+
+// DiffEvalMetrics returns m minus baseline.
+func DiffEvalMetrics(runID string, m, baseline *EvalMetrics) *EvalMetricsDelta {
+	delta := &EvalMetricsDelta{RunID: runID}
+	if m == nil || baseline == nil {
+		return delta
+	}
+	delta.RecallAtK = m.RecallAtK - baseline.RecallAtK
+	delta.MRR = m.MRR - baseline.MRR
+	delta.NDCGAtK = m.NDCGAtK - baseline.NDCGAtK
+	delta.HitRateAtK = m.HitRateAtK - baseline.HitRateAtK
+	delta.AvgLatencyMs = m.AvgLatencyMs - baseline.AvgLatencyMs
+	delta.Faithfulness = diffOptional(m.Faithfulness, baseline.Faithfulness)
+	delta.AnswerRelevance = diffOptional(m.AnswerRelevance, baseline.AnswerRelevance)
+	return delta
+}
+
+func diffOptional(v, baseline *float64) *float64 {
+	if v == nil || baseline == nil {
+		return nil
+	}
+	d := *v - *baseline
+	return &d
+}
+
+// EvalItemComparison lists the metrics of one question across the compared
+// runs, in the order of the runs.
+type EvalItemComparison struct {
+	ItemID   string         `json:"item_id"`
+	Question string         `json:"question"`
+	Metrics  []*EvalMetrics `json:"metrics"`
+}
+
+// EvalRunComparison compares runs against the first of them, the baseline.
+type EvalRunComparison struct {
+	BaselineID string              `json:"baseline_id"`
+	Runs       []*EvalRun          `json:"runs"`
+	Deltas     []*EvalMetricsDelta `json:"deltas"`
+	// Items is only filled when every run evaluated the same dataset
+	Items []*EvalItemComparison `json:"items,omitempty"`
+}
@@ -0,0 +1,144 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalExpectedSourcesMatchRanking(t *testing.T) {
+	results := []*SearchResult{
+		{ID: "c1", KnowledgeID: "k1", KnowledgeTitle: "Handbook"},
+		{ID: "c2", KnowledgeID: "k1", KnowledgeTitle: "Handbook"},
+		{ID: "c3", KnowledgeID: "k2", KnowledgeFilename: "pricing.pdf"},
+		{ID: "c4", ParentChunkID: "p1", KnowledgeID: "k3"},
+	}
+	sources := EvalExpectedSources{
+		{Title: " pricing.PDF "},
+		{KnowledgeID: "k1"},
+		{ChunkID: "p1"},
+		{ChunkID: "missing"},
+	}
+	assert.Equal(t, []int{1, -1, 0, 2}, sources.MatchRanking(results),
+		"a document source counts once, at its first chunk")
+	assert.Empty(t, sources.MatchRanking(nil))
+}
+
+func TestEvalDatasetRequestValidate(t *testing.T) {
+	valid := func() *EvalDatasetRequest {
+		return &EvalDatasetRequest{
+			Name: " golden ",
+			Items: []*EvalDatasetItemRequest{
+				{Question: " How do refunds work? ", ExpectedSources: []EvalExpectedSource{{Title: "Refunds"}}},
+				{Question: "Who founded the company?", ExpectedAnswer: "Alice"},
+			},
+		}
+	}
+
+	req := valid()
+	require.NoError(t, req.Validate())
+	assert.Equal(t, "golden", req.Name)
+	assert.Equal(t, "How do refunds work?", req.Items[0].Question)
+
+	req = valid()
+	req.Name = ""
+	assert.Error(t, req.Validate())
+
+	req = valid()
+	req.Items = nil
+	assert.Error(t, req.Validate())
+
+	req = valid()
+	req.Items[0].Question = " "
+	assert.Error(t, req.Validate())
+
+	req = valid()
+	req.Items[1].ExpectedAnswer = ""
+	assert.Error(t, req.Validate(), "an item must have something to score")
+
+	req = valid()
+	req.Items[0].ExpectedSources = []EvalExpectedSource{{ChunkID: "c1", Title: "Refunds"}}
+	assert.Error(t, req.Validate(), "a source sets exactly one field")
+
+	req = valid()
+	req.Items[0].ExpectedSources = []EvalExpectedSource{{}}
+	assert.Error(t, req.Validate())
+}
+
+func TestEvalRunConfigValidate(t *testing.T) {
+	cfg := &EvalRunConfig{}
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, EvalRunModeRetrieval, cfg.Mode)
+	assert.Equal(t, DefaultEvalTopK, cfg.TopK)
+
+	assert.Error(t, (&EvalRunConfig{Mode: "agent"}).Validate())
+	assert.Error(t, (&EvalRunConfig{TopK: MaxEvalTopK + 1}).Validate())
+	assert.Error(t, (&EvalRunConfig{RerankTopK: -1}).Validate())
+	assert.NoError(t, (&EvalRunConfig{Mode: EvalRunModeRAG, TopK: 5}).Validate())
+}
+
+func TestDiffEvalMetrics(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	baseline := &EvalMetrics{RecallAtK: 0.5, MRR: 0.4, NDCGAtK: 0.45, HitRateAtK: 0.6, Faithfulness: f(0.8), AvgLatencyMs: 300}
+	run := &EvalMetrics{RecallAtK: 0.75, MRR: 0.5, NDCGAtK: 0.5, HitRateAtK: 0.8, Faithfulness: f(0.9),
+		AnswerRelevance: f(0.7), AvgLatencyMs: 250}
+
+	delta := DiffEvalMetrics("r2", run, baseline)
+	assert.Equal(t, "r2", delta.RunID)
+	assert.InDelta(t, 0.25, delta.RecallAtK, 1e-9)
+	assert.InDelta(t, 0.1, delta.MRR, 1e-9)
+	assert.InDelta(t, 0.05, delta.NDCGAtK, 1e-9)
+	assert.InDelta(t, 0.2, delta.HitRateAtK, 1e-9)
+	assert.Equal(t, int64(-50), delta.AvgLatencyMs)
+	require.NotNil(t, delta.Faithfulness)
+	assert.InDelta(t, 0.1, *delta.Faithfulness, 1e-9)
+	assert.Nil(t, delta.AnswerRelevance, "the baseline has no answer relevance")
+
+	assert.Equal(t, &EvalMetricsDelta{RunID: "r3"}, DiffEvalMetrics("r3", nil, baseline))
+}
+
+func TestAverageEvalMetrics(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+	results := []*EvalRunResult{
+		{ExpectedSources: 2, Metrics: EvalMetrics{RecallAtK: 1, MRR: 1, NDCGAtK: 1, HitRateAtK: 1,
+			Faithfulness: f(1), AnswerRelevance: f(0.5), AvgLatencyMs: 100}},
+		{ExpectedSources: 1, Metrics: EvalMetrics{RecallAtK: 0, MRR: 0, NDCGAtK: 0, HitRateAtK: 0,
+			Faithfulness: f(0.5), AvgLatencyMs: 300}},
+		// answer-only question: no retrieval metrics
+		{Metrics: EvalMetrics{AvgLatencyMs: 200}},
+		// failed question: skipped entirely
+		{ExpectedSources: 1, ErrMsg: "boom", Metrics: EvalMetrics{RecallAtK: 1, AvgLatencyMs: 5000}},
+	}
+
+	avg := AverageEvalMetrics(results)
+	assert.InDelta(t, 0.5, avg.RecallAtK, 1e-9)
+	assert.InDelta(t, 0.5, avg.MRR, 1e-9)
+	assert.InDelta(t, 0.5, avg.NDCGAtK, 1e-9)
+	assert.InDelta(t, 0.5, avg.HitRateAtK, 1e-9)
+	assert.Equal(t, int64(200), avg.AvgLatencyMs)
+	require.NotNil(t, avg.Faithfulness)
+	assert.InDelta(t, 0.75, *avg.Faithfulness, 1e-9)
+	require.NotNil(t, avg.AnswerRelevance)
+	assert.InDelta(t, 0.5, *avg.AnswerRelevance, 1e-9)
+
+	assert.Equal(t, &EvalMetrics{}, AverageEvalMetrics(nil))
+}
+
+func TestParseEvalDatasetItems(t *testing.T) {
+	jsonl := "\xef\xbb\xbf{\"question\":\"q1\",\"expected_sources\":[{\"title\":\"Handbook\"}]}\n\n" +
+		"{\"question\":\"q2\",\"expected_answer\":\"a2\"}\r\n"
+	items, err := ParseEvalDatasetItems([]byte(jsonl))
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, []EvalExpectedSource{{Title: "Handbook"}}, items[0].ExpectedSources)
+	assert.Equal(t, "a2", items[1].ExpectedAnswer)
+
+	items, err = ParseEvalDatasetItems([]byte(` [{"question":"q1","expected_answer":"a1"}]`))
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "q1", items[0].Question)
+
+	_, err = ParseEvalDatasetItems([]byte("{\"question\":\"q1\"}\nnot json"))
+	assert.ErrorContains(t, err, "line 2")
+}
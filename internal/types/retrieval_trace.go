@@ -0,0 +1,92 @@
+package types
+
+import (
+	"context"
+	"sync"
+)
+
+// ChunkExclusionReason explains why a retrieval candidate did not make it
+// into the final result set.
+type ChunkExclusionReason string
+
+const (
+	// ChunkExclusionBelowThreshold: the rerank score was under the
+	// configured rerank threshold.
+	ChunkExclusionBelowThreshold ChunkExclusionReason = "below_threshold"
+	// ChunkExclusionDeduplicated: the content duplicated, or was largely
+	// contained in, another kept candidate.
+	ChunkExclusionDeduplicated ChunkExclusionReason = "deduplicated"
+	// ChunkExclusionTokenBudget: the candidate did not fit in the context
+	// token budget of the answering model.
+	ChunkExclusionTokenBudget ChunkExclusionReason = "token_budget"
+	// ChunkExclusionDisabledChunk: the chunk is disabled but was still
+	// returned by the index (e.g. the flag had not been synced yet).
+	ChunkExclusionDisabledChunk ChunkExclusionReason = "disabled_chunk"
+	// ChunkExclusionACL: the chunk belongs to a shared knowledge base the
+	// caller has no permission to read.
+	ChunkExclusionACL ChunkExclusionReason = "acl"
+	// ChunkExclusionTopK: the candidate ranked below the top-K cut-off.
+	ChunkExclusionTopK ChunkExclusionReason = "top_k"
+	// ChunkExclusionInactiveGeneration: the chunk was staged by an index
+	// migration that has not switched the knowledge base over yet.
+	ChunkExclusionInactiveGeneration ChunkExclusionReason = "inactive_generation"
+	// ChunkExclusionGuardrail: the content matched a guardrails rule whose
+	// action is block.
+	ChunkExclusionGuardrail ChunkExclusionReason = "guardrail"
+)
+
+// ChunkExclusion records the fate of one dropped candidate.
+type ChunkExclusion struct {
+	ChunkID     string `json:"chunk_id"`
+	KnowledgeID string `json:"knowledge_id,omitempty"`
+	// Stage is the pipeline stage that dropped the candidate
+	// (search, rerank, merge, filter_top_k).
+	Stage  string               `json:"stage"`
+	Reason ChunkExclusionReason `json:"reason"`
+	// Score is the candidate's score when it was dropped, if any.
+	Score float64 `json:"score,omitempty"`
+	// Detail carries reason-specific context such as the threshold or
+	// the ID of the chunk a duplicate was folded into.
+	Detail string `json:"detail,omitempty"`
+}
+
+// RetrievalTrace collects exclusion decisions made while answering one
+// request. It is only attached when the caller asked for a debug trace and
+// is safe for concurrent use; a nil trace silently drops records so stages
+// never need to check whether tracing is on.
+type RetrievalTrace struct {
+	mu         sync.Mutex
+	exclusions []ChunkExclusion
+}
+
+// Exclude records a dropped candidate.
+func (t *RetrievalTrace) Exclude(e ChunkExclusion) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.exclusions = append(t.exclusions, e)
+}
+
+// Exclusions returns a copy of the recorded exclusions in record order.
+func (t *RetrievalTrace) Exclusions() []ChunkExclusion {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]ChunkExclusion(nil), t.exclusions...)
+}
+
+// WithRetrievalTrace attaches a fresh trace to ctx.
+func WithRetrievalTrace(ctx context.Context) (context.Context, *RetrievalTrace) {
+	t := &RetrievalTrace{}
+	return context.WithValue(ctx, RetrievalTraceContextKey, t), t
+}
+
+// RetrievalTraceFromContext returns the trace attached to ctx, or nil.
+func RetrievalTraceFromContext(ctx context.Context) *RetrievalTrace {
+	t, _ := ctx.Value(RetrievalTraceContextKey).(*RetrievalTrace)
+	return t
+}
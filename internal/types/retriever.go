@@ -34,6 +34,7 @@ const (
 	KeywordsRetrieverType  RetrieverType = "keywords"  // Keywords retriever
 	VectorRetrieverType    RetrieverType = "vector"    // Vector retriever
 	WebSearchRetrieverType RetrieverType = "websearch" // Web search retriever
+	GraphRetrieverType     RetrieverType = "graph"     // Graph community report retriever
 )
 
 // RetrieveParams represents the parameters for retrieval
@@ -42,6 +43,9 @@ type RetrieveParams struct {
 	Query string
 	// Query embedding (used for vector retrieval)
 	Embedding []float32
+	// EmbeddingModelID is the model that produced Embedding. Engines with
+	// per-model vector spaces search only the space of this model.
+	EmbeddingModelID string
 	// Knowledge base IDs
 	KnowledgeBaseIDs []string
 	// Knowledge IDs
@@ -52,6 +56,17 @@ type RetrieveParams struct {
 	ExcludeKnowledgeIDs []string
 	// Excluded chunk IDs
 	ExcludeChunkIDs []string
+	// StagedChunkIDs are the chunks an in-flight index migration has staged
+	// in scope; engines filter them out like ExcludeChunkIDs, see
+	// ExcludedChunkIDs
+	StagedChunkIDs []string
+	// Principal is the caller the retrieval runs for. It is left out of the
+	// retrieve cache key; ACLDeniedKnowledgeIDs carries its effect
+	Principal *RetrievalPrincipal `json:"-"`
+	// ACLDeniedKnowledgeIDs are the documents in scope whose ACL the
+	// principal does not match; engines filter them out like
+	// ExcludeKnowledgeIDs, see ExcludedKnowledgeIDs
+	ACLDeniedKnowledgeIDs []string
 	// Number of results to return
 	TopK int
 	// Similarity threshold
@@ -64,6 +79,34 @@ type RetrieveParams struct {
 	RetrieverType RetrieverType // Retriever type
 }
 
+// ExcludedKnowledgeIDs returns the knowledge IDs an engine must filter out:
+// the explicitly excluded ones and those denied by document ACLs.
+func (p RetrieveParams) ExcludedKnowledgeIDs() []string {
+	if len(p.ACLDeniedKnowledgeIDs) == 0 {
+		return p.ExcludeKnowledgeIDs
+	}
+	if len(p.ExcludeKnowledgeIDs) == 0 {
+		return p.ACLDeniedKnowledgeIDs
+	}
+	ids := make([]string, 0, len(p.ExcludeKnowledgeIDs)+len(p.ACLDeniedKnowledgeIDs))
+	ids = append(ids, p.ExcludeKnowledgeIDs...)
+	return append(ids, p.ACLDeniedKnowledgeIDs...)
+}
+
+// ExcludedChunkIDs returns the chunk IDs an engine must filter out: the
+// explicitly excluded ones and those staged by an index migration.
+func (p RetrieveParams) ExcludedChunkIDs() []string {
+	if len(p.StagedChunkIDs) == 0 {
+		return p.ExcludeChunkIDs
+	}
+	if len(p.ExcludeChunkIDs) == 0 {
+		return p.StagedChunkIDs
+	}
+	ids := make([]string, 0, len(p.ExcludeChunkIDs)+len(p.StagedChunkIDs))
+	ids = append(ids, p.ExcludeChunkIDs...)
+	return append(ids, p.StagedChunkIDs...)
+}
+
 // RetrieverEngineParams represents the parameters for retriever engine
 type RetrieverEngineParams struct {
 	// Retriever engine type
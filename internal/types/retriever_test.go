@@ -0,0 +1,15 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetrieveParams_ExcludedChunkIDs(t *testing.T) {
+	assert.Nil(t, RetrieveParams{}.ExcludedChunkIDs())
+	assert.Equal(t, []string{"a"}, RetrieveParams{ExcludeChunkIDs: []string{"a"}}.ExcludedChunkIDs())
+	assert.Equal(t, []string{"s"}, RetrieveParams{StagedChunkIDs: []string{"s"}}.ExcludedChunkIDs())
+	assert.Equal(t, []string{"a", "s"},
+		RetrieveParams{ExcludeChunkIDs: []string{"a"}, StagedChunkIDs: []string{"s"}}.ExcludedChunkIDs())
+}
@@ -32,12 +32,19 @@ type SearchTarget struct {
 	// Required for cross-tenant shared KB queries
 	TenantID uint64 `json:"tenant_id"`
 	// KnowledgeIDs is the list of specific knowledge IDs to search within the knowledge base
-	// Only used when Type is SearchTargetTypeKnowledge
+	// Set when Type is SearchTargetTypeKnowledge, or on a knowledge base target
+	// whose tag filter was resolved to the documents carrying the tags
 	KnowledgeIDs []string `json:"knowledge_ids,omitempty"`
 	// TagIDs limits retrieval to chunks/documents carrying any of these KB-local tags.
 	TagIDs []string `json:"tag_ids,omitempty"`
 }
 
+// IsWholeKnowledgeBase reports whether the target searches its entire
+// knowledge base, with no document or tag filter.
+func (t *SearchTarget) IsWholeKnowledgeBase() bool {
+	return t.Type == SearchTargetTypeKnowledgeBase && len(t.TagIDs) == 0 && len(t.KnowledgeIDs) == 0
+}
+
 // SearchTargets is a list of search targets, pre-computed at request entry point
 type SearchTargets []*SearchTarget
 
@@ -165,6 +172,13 @@ type SearchParams struct {
 	// in processSearchResults. Used by the chat pipeline where context assembly
 	// is handled separately in the merge stage.
 	SkipContextEnrichment bool `json:"skip_context_enrichment,omitempty"`
+	// Principal and ACLDeniedKnowledgeIDs are resolved by HybridSearch from
+	// the caller and the document ACLs of the searched knowledge bases
+	Principal             *RetrievalPrincipal `json:"-"`
+	ACLDeniedKnowledgeIDs []string            `json:"-"`
+	// StagedChunkIDs are resolved by HybridSearch from the index migrations
+	// running on the searched knowledge bases
+	StagedChunkIDs []string `json:"-"`
 }
 
 // Value implements the driver.Valuer interface, used to convert SearchResult to database value
@@ -97,6 +97,18 @@ type Session struct {
 	// avoid a new migration; the shape used today is `SessionLastRequestState`.
 	LastRequestState *SessionLastRequestState `json:"last_request_state,omitempty" gorm:"column:agent_config;type:jsonb"`
 
+	// MemoryPolicy controls when the session's turns are stored in the
+	// user's long-term memory; nil stores every turn.
+	MemoryPolicy *MemoryPolicy `json:"memory_policy,omitempty" gorm:"type:jsonb"`
+
+	// RerankConfig turns the rerank stage on or off and sets its top-N for
+	// the session; nil keeps the agent's settings.
+	RerankConfig *SessionRerankConfig `json:"rerank_config,omitempty" gorm:"type:jsonb"`
+
+	// PipelineConfig sets the knowledge QA pipeline stages for the session
+	// over the agent's and the tenant's settings; nil keeps them.
+	PipelineConfig *PipelineConfig `json:"pipeline_config,omitempty" gorm:"type:jsonb"`
+
 	// // Strategy configuration
 	// KnowledgeBaseID   string              `json:"knowledge_base_id"`                    // 关联的知识库ID
 	// MaxRounds         int                 `json:"max_rounds"`                           // 多轮保持轮数
@@ -166,14 +178,13 @@ func (c StringArray) Value() (driver.Value, error) {
 
 // Scan implements the sql.Scanner interface, used to convert database value to StringArray
 func (c *StringArray) Scan(value interface{}) error {
-	if value == nil {
-		return nil
-	}
-	b, ok := value.([]byte)
-	if !ok {
-		return nil
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, c)
+	case string:
+		return json.Unmarshal([]byte(v), c)
 	}
-	return json.Unmarshal(b, c)
+	return nil
 }
 
 // Value implements the driver.Valuer interface, used to convert SummaryConfig to database value
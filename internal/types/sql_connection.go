@@ -0,0 +1,199 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/Tencent/WeKnora/internal/utils"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SQLDriver is the kind of database a SQL connection talks to
+type SQLDriver string
+
+const (
+	SQLDriverPostgres SQLDriver = "postgres"
+	SQLDriverMySQL    SQLDriver = "mysql"
+)
+
+const (
+	// DefaultSQLMaxRows is the number of result rows fed into the answer
+	// when a connection does not set one.
+	DefaultSQLMaxRows = 100
+	// MaxSQLMaxRows caps the rows a connection may return per query.
+	MaxSQLMaxRows = 1000
+	// DefaultSQLQueryTimeout is the query timeout in seconds when a
+	// connection does not set one.
+	DefaultSQLQueryTimeout = 10
+	// MaxSQLQueryTimeout caps the query timeout in seconds.
+	MaxSQLQueryTimeout = 120
+)
+
+// IsValid reports whether d is a supported driver
+func (d SQLDriver) IsValid() bool {
+	switch d {
+	case SQLDriverPostgres, SQLDriverMySQL:
+		return true
+	default:
+		return false
+	}
+}
+
+// SQLConnection is a database a tenant lets knowledge QA answer analytical
+// questions from. Questions are routed to a connection by its description,
+// answered with generated read-only SQL, and the result table is fed into
+// the answer together with the SQL.
+type SQLConnection struct {
+	// Unique identifier (UUID, auto-generated)
+	ID string `yaml:"id" json:"id" gorm:"type:varchar(36);primaryKey"`
+	// Tenant ID for scoping
+	TenantID uint64 `yaml:"tenant_id" json:"tenant_id"`
+	// User-friendly name, e.g., "Sales warehouse"
+	Name string `yaml:"name" json:"name" gorm:"type:varchar(255);not null"`
+	// What the database holds; used to route questions to it
+	Description string `yaml:"description" json:"description" gorm:"type:text"`
+	// Database driver: postgres or mysql
+	Driver SQLDriver `yaml:"driver" json:"driver" gorm:"type:varchar(50);not null"`
+	// Connection parameters, password encrypted at rest
+	Parameters SQLConnectionParameters `yaml:"parameters" json:"parameters" gorm:"type:json"`
+	// Tables the generated SQL may read; empty allows every table the
+	// database user can see
+	AllowedTables StringArray `yaml:"allowed_tables" json:"allowed_tables" gorm:"type:json"`
+	// Maximum rows returned per query (0 = DefaultSQLMaxRows)
+	MaxRows int `yaml:"max_rows" json:"max_rows"`
+	// Query timeout in seconds (0 = DefaultSQLQueryTimeout)
+	QueryTimeout int `yaml:"query_timeout" json:"query_timeout"`
+	// Timestamps
+	CreatedAt time.Time      `yaml:"created_at" json:"created_at"`
+	UpdatedAt time.Time      `yaml:"updated_at" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `yaml:"deleted_at" json:"deleted_at" gorm:"index"`
+}
+
+// TableName returns the table name for SQLConnection
+func (SQLConnection) TableName() string {
+	return "sql_connections"
+}
+
+// BeforeCreate is a GORM hook that generates a UUID for new connections.
+func (c *SQLConnection) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == "" {
+		c.ID = uuid.New().String()
+	}
+	return nil
+}
+
+// EffectiveMaxRows returns MaxRows clamped to (0, MaxSQLMaxRows], or the
+// default when it is not set.
+func (c *SQLConnection) EffectiveMaxRows() int {
+	if c.MaxRows <= 0 {
+		return DefaultSQLMaxRows
+	}
+	return min(c.MaxRows, MaxSQLMaxRows)
+}
+
+// EffectiveQueryTimeout returns the query timeout clamped to
+// MaxSQLQueryTimeout, or the default when it is not set.
+func (c *SQLConnection) EffectiveQueryTimeout() time.Duration {
+	seconds := c.QueryTimeout
+	if seconds <= 0 {
+		seconds = DefaultSQLQueryTimeout
+	}
+	return time.Duration(min(seconds, MaxSQLQueryTimeout)) * time.Second
+}
+
+// SQLConnectionParameters holds where and how to connect. The password is
+// encrypted at rest using AES-GCM and never returned in responses.
+type SQLConnectionParameters struct {
+	// Host name or IP of the database server
+	Host string `yaml:"host" json:"host"`
+	// Port of the database server (0 = driver default)
+	Port int `yaml:"port" json:"port,omitempty"`
+	// Database (schema for MySQL) to connect to
+	Database string `yaml:"database" json:"database"`
+	// User to connect as; should only be granted read access
+	Username string `yaml:"username" json:"username"`
+	// Password (encrypted in DB)
+	Password string `yaml:"password" json:"password,omitempty"`
+	// PostgreSQL sslmode (disable, require, verify-full, ...)
+	SSLMode string `yaml:"ssl_mode" json:"ssl_mode,omitempty"`
+}
+
+// Value implements the driver.Valuer interface.
+// Encrypts Password before persisting to database.
+func (p SQLConnectionParameters) Value() (driver.Value, error) {
+	if key := utils.GetAESKey(); key != nil && p.Password != "" {
+		if encrypted, err := utils.EncryptAESGCM(p.Password, key); err == nil {
+			p.Password = encrypted
+		}
+	}
+	return json.Marshal(p)
+}
+
+// Scan implements the sql.Scanner interface.
+// Decrypts Password after loading from database.
+func (p *SQLConnectionParameters) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	b, ok := value.([]byte)
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal(b, p); err != nil {
+		return err
+	}
+	if plain, ok := utils.DecryptStoredSecretLenient(p.Password); ok {
+		p.Password = plain
+	} else {
+		log.Printf("[crypto] sql connection password: decrypt failed (SYSTEM_AES_KEY missing/rotated?), treating as unconfigured")
+		p.Password = ""
+	}
+	return nil
+}
+
+// SQLQueryResult is the outcome of a generated query: the SQL that ran and
+// the rows it returned, as display strings.
+type SQLQueryResult struct {
+	SQL     string     `json:"sql"`
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+	// Truncated is set when the query had more rows than were returned
+	Truncated bool `json:"truncated"`
+}
+
+// Markdown renders the result as a Markdown table. Pipes and line breaks in
+// cells are escaped so every row stays on one line.
+func (r *SQLQueryResult) Markdown() string {
+	if len(r.Columns) == 0 {
+		return "(no columns)"
+	}
+	escape := func(s string) string {
+		s = strings.ReplaceAll(s, "|", `\|`)
+		return strings.Join(strings.Fields(s), " ")
+	}
+	var b strings.Builder
+	b.WriteString("|")
+	for _, c := range r.Columns {
+		b.WriteString(" " + escape(c) + " |")
+	}
+	b.WriteString("\n|")
+	for range r.Columns {
+		b.WriteString(" --- |")
+	}
+	for _, row := range r.Rows {
+		b.WriteString("\n|")
+		for _, cell := range row {
+			b.WriteString(" " + escape(cell) + " |")
+		}
+	}
+	if len(r.Rows) == 0 {
+		b.WriteString("\n\n(no rows)")
+	} else if r.Truncated {
+		b.WriteString("\n\n(more rows omitted)")
+	}
+	return b.String()
+}
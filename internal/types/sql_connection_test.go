@@ -0,0 +1,42 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSQLConnectionEffectiveLimits(t *testing.T) {
+	c := &SQLConnection{}
+	assert.Equal(t, DefaultSQLMaxRows, c.EffectiveMaxRows())
+	assert.Equal(t, DefaultSQLQueryTimeout*time.Second, c.EffectiveQueryTimeout())
+
+	c = &SQLConnection{MaxRows: 50, QueryTimeout: 30}
+	assert.Equal(t, 50, c.EffectiveMaxRows())
+	assert.Equal(t, 30*time.Second, c.EffectiveQueryTimeout())
+
+	c = &SQLConnection{MaxRows: 100000, QueryTimeout: 100000}
+	assert.Equal(t, MaxSQLMaxRows, c.EffectiveMaxRows())
+	assert.Equal(t, MaxSQLQueryTimeout*time.Second, c.EffectiveQueryTimeout())
+}
+
+func TestSQLDriverIsValid(t *testing.T) {
+	assert.True(t, SQLDriverPostgres.IsValid())
+	assert.True(t, SQLDriverMySQL.IsValid())
+	assert.False(t, SQLDriver("oracle").IsValid())
+}
+
+func TestSQLQueryResultMarkdown(t *testing.T) {
+	r := &SQLQueryResult{
+		Columns: []string{"region", "total"},
+		Rows:    [][]string{{"north|east", "10"}, {"south\nwest", "20"}},
+	}
+	assert.Equal(t, "| region | total |\n| --- | --- |\n| north\\|east | 10 |\n| south west | 20 |", r.Markdown())
+
+	r.Truncated = true
+	assert.Contains(t, r.Markdown(), "(more rows omitted)")
+
+	empty := &SQLQueryResult{Columns: []string{"n"}}
+	assert.Equal(t, "| n |\n| --- |\n\n(no rows)", empty.Markdown())
+}
@@ -18,6 +18,8 @@ type KnowledgeTag struct {
 	TenantID uint64 `json:"tenant_id"`
 	// Knowledge base ID that this tag belongs to
 	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36);index"`
+	// Parent tag ID within the same knowledge base; empty for a top-level tag
+	ParentID string `json:"parent_id"         gorm:"type:varchar(36);index"`
 	// Tag name, unique within the same knowledge base
 	Name string `json:"name"              gorm:"type:varchar(128);not null"`
 	// Optional display color
@@ -28,6 +30,9 @@ type KnowledgeTag struct {
 	CreatedAt time.Time `json:"created_at"`
 	// Last updated time
 	UpdatedAt time.Time `json:"updated_at"`
+	// Source is how the tag was attached to a document (manual or auto);
+	// only set when the tag is listed as one of a document's tags
+	Source string `json:"source,omitempty"  gorm:"-"`
 }
 
 // BeforeCreate ensures SeqID is populated for databases that don't support
@@ -52,6 +57,59 @@ func (t *KnowledgeTag) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// ExpandTagDescendants returns tagIDs followed by the IDs of all their
+// descendants in tags, the tags of one knowledge base. IDs not found in tags
+// are kept as given. The result has no duplicates.
+func ExpandTagDescendants(tags []*KnowledgeTag, tagIDs []string) []string {
+	children := make(map[string][]string, len(tags))
+	for _, tag := range tags {
+		if tag != nil && tag.ParentID != "" {
+			children[tag.ParentID] = append(children[tag.ParentID], tag.ID)
+		}
+	}
+	seen := make(map[string]bool, len(tagIDs))
+	result := make([]string, 0, len(tagIDs))
+	queue := make([]string, 0, len(tagIDs))
+	for _, id := range tagIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+			queue = append(queue, id)
+		}
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, child := range children[id] {
+			if !seen[child] {
+				seen[child] = true
+				result = append(result, child)
+				queue = append(queue, child)
+			}
+		}
+	}
+	return result
+}
+
+// IsTagInSubtree reports whether id is rootID or one of its descendants in
+// tags, the tags of one knowledge base.
+func IsTagInSubtree(tags []*KnowledgeTag, id string, rootID string) bool {
+	parents := make(map[string]string, len(tags))
+	for _, tag := range tags {
+		if tag != nil {
+			parents[tag.ID] = tag.ParentID
+		}
+	}
+	// The walk is bounded by the tag count so a corrupted cycle cannot loop.
+	for i := 0; id != "" && i <= len(tags); i++ {
+		if id == rootID {
+			return true
+		}
+		id = parents[id]
+	}
+	return false
+}
+
 // KnowledgeTagWithStats represents tag information along with usage statistics.
 type KnowledgeTagWithStats struct {
 	KnowledgeTag
@@ -71,6 +129,8 @@ type KnowledgeTagRelation struct {
 	KnowledgeID string    `gorm:"type:varchar(36);primaryKey"`
 	TagID       string    `gorm:"type:varchar(36);primaryKey"`
 	CreatedAt   time.Time `gorm:"autoCreateTime"`
+	// Source is KnowledgeTagSourceManual or KnowledgeTagSourceAuto
+	Source string `gorm:"type:varchar(16);default:manual"`
 }
 
 // TableName overrides the default table name.
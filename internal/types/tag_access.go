@@ -0,0 +1,70 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// Tag access rule subject types.
+const (
+	// TagAccessSubjectUser restricts one tenant member, SubjectID is the user ID
+	TagAccessSubjectUser = "user"
+	// TagAccessSubjectRole restricts every member with a tenant role,
+	// SubjectID is the TenantRole
+	TagAccessSubjectRole = "role"
+	// TagAccessSubjectAPIKey restricts requests authenticated with the
+	// tenant's API key; SubjectID is empty
+	TagAccessSubjectAPIKey = "api_key"
+)
+
+// TagAccessRule restricts what a subject retrieves from a knowledge base to
+// the documents and FAQ entries under some tag subtrees. A caller without a
+// rule on a knowledge base searches all of it. When several rules match a
+// caller, a user rule wins over an API key rule, which wins over a role rule.
+// Rules apply to callers of the tenant owning the knowledge base only.
+type TagAccessRule struct {
+	ID              string `json:"id"                gorm:"type:varchar(36);primaryKey"`
+	TenantID        uint64 `json:"tenant_id"         gorm:"index"`
+	KnowledgeBaseID string `json:"knowledge_base_id" gorm:"type:varchar(36)"`
+	SubjectType     string `json:"subject_type"      gorm:"type:varchar(16)"`
+	SubjectID       string `json:"subject_id"        gorm:"type:varchar(64)"`
+	// TagIDs are the roots of the allowed subtrees; descendants of a root,
+	// including tags created later, are allowed too
+	TagIDs    StringArray `json:"tag_ids"    gorm:"type:json"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// TableName returns the table name of TagAccessRule
+func (TagAccessRule) TableName() string {
+	return "tag_access_rules"
+}
+
+// TagAccessSubject identifies who a tag access rule applies to.
+type TagAccessSubject struct {
+	Type string
+	ID   string
+}
+
+// TagAccessSubjectsFromContext returns the subjects of the caller in ctx, most
+// specific first.
+func TagAccessSubjectsFromContext(ctx context.Context) []TagAccessSubject {
+	var subjects []TagAccessSubject
+	if apiKey, _ := ctx.Value(APIKeyAuthContextKey).(bool); apiKey {
+		subjects = append(subjects, TagAccessSubject{Type: TagAccessSubjectAPIKey})
+	} else if userID, _ := ctx.Value(UserIDContextKey).(string); userID != "" {
+		subjects = append(subjects, TagAccessSubject{Type: TagAccessSubjectUser, ID: userID})
+	}
+	if role, ok := ctx.Value(TenantRoleContextKey).(TenantRole); ok && role.IsValid() {
+		subjects = append(subjects, TagAccessSubject{Type: TagAccessSubjectRole, ID: string(role)})
+	}
+	return subjects
+}
+
+// TagAccessRuleRequest creates or replaces the rule of a subject on a
+// knowledge base.
+type TagAccessRuleRequest struct {
+	SubjectType string   `json:"subject_type" binding:"required"`
+	SubjectID   string   `json:"subject_id"`
+	TagIDs      []string `json:"tag_ids"      binding:"required"`
+}
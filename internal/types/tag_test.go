@@ -0,0 +1,83 @@
+package types
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func tagTree() []*KnowledgeTag {
+	// dept
+	// ├── team-a
+	// │   └── project
+	// └── team-b
+	// other
+	return []*KnowledgeTag{
+		{ID: "dept"},
+		{ID: "team-a", ParentID: "dept"},
+		{ID: "project", ParentID: "team-a"},
+		{ID: "team-b", ParentID: "dept"},
+		{ID: "other"},
+	}
+}
+
+func TestExpandTagDescendants(t *testing.T) {
+	cases := []struct {
+		name   string
+		tagIDs []string
+		want   []string
+	}{
+		{"root expands to subtree", []string{"dept"}, []string{"dept", "team-a", "team-b", "project"}},
+		{"leaf stays alone", []string{"project"}, []string{"project"}},
+		{"overlapping roots deduplicated", []string{"team-a", "dept"}, []string{"team-a", "dept", "project", "team-b"}},
+		{"unknown id kept", []string{"missing", "other"}, []string{"missing", "other"}},
+		{"empty", nil, []string{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := ExpandTagDescendants(tagTree(), c.tagIDs)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("ExpandTagDescendants(%v) = %v, want %v", c.tagIDs, got, c.want)
+			}
+		})
+	}
+}
+
+func TestIsTagInSubtree(t *testing.T) {
+	tags := tagTree()
+	if !IsTagInSubtree(tags, "project", "dept") {
+		t.Fatal("project should be in the dept subtree")
+	}
+	if !IsTagInSubtree(tags, "dept", "dept") {
+		t.Fatal("a tag should be in its own subtree")
+	}
+	if IsTagInSubtree(tags, "dept", "team-a") {
+		t.Fatal("dept should not be in the team-a subtree")
+	}
+	if IsTagInSubtree(tags, "other", "dept") {
+		t.Fatal("other should not be in the dept subtree")
+	}
+
+	cyclic := []*KnowledgeTag{{ID: "a", ParentID: "b"}, {ID: "b", ParentID: "a"}}
+	if IsTagInSubtree(cyclic, "a", "c") {
+		t.Fatal("a cycle should not loop or match")
+	}
+}
+
+func TestTagAccessSubjectsFromContext(t *testing.T) {
+	ctx := context.WithValue(context.Background(), UserIDContextKey, "u1")
+	ctx = context.WithValue(ctx, TenantRoleContextKey, TenantRoleViewer)
+	want := []TagAccessSubject{
+		{Type: TagAccessSubjectUser, ID: "u1"},
+		{Type: TagAccessSubjectRole, ID: string(TenantRoleViewer)},
+	}
+	if got := TagAccessSubjectsFromContext(ctx); !reflect.DeepEqual(got, want) {
+		t.Fatalf("user subjects = %v, want %v", got, want)
+	}
+
+	ctx = context.WithValue(ctx, APIKeyAuthContextKey, true)
+	want[0] = TagAccessSubject{Type: TagAccessSubjectAPIKey}
+	if got := TagAccessSubjectsFromContext(ctx); !reflect.DeepEqual(got, want) {
+		t.Fatalf("API key subjects = %v, want %v", got, want)
+	}
+}
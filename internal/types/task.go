@@ -21,6 +21,14 @@ const (
 	// question batches from starving the lightweight tasks in the low queue
 	// (summary, deletes, wiki ingest).
 	QueueQuestion = "question"
+	// QueueMemory isolates conversation memory extraction (one LLM call per
+	// chat turn) so a burst of chats cannot delay document processing.
+	QueueMemory = "memory"
+	// QueueBulk carries document:process tasks from bulk ingestion (data
+	// source syncs, batch reparse, archive imports) so a sync of thousands
+	// of files cannot delay a document a user just uploaded in the default
+	// queue.
+	QueueBulk = "bulk"
 )
 
 const (
@@ -41,6 +49,18 @@ const (
 	TypeManualProcess        = "manual:process"         // 手工知识更新任务（cleanup + 重新索引）
 	TypeDataSourceSync       = "datasource:sync"        // 数据源同步任务
 	TypeWikiIngest           = "wiki:ingest"            // Wiki 页面同步任务
+	TypeGlossaryBuild        = "glossary:build"         // 知识库术语表构建任务
+	TypeIndexMigration       = "kb:index_migration"     // 知识库索引迁移任务（按新代次重建分块与索引）
+	TypeFilePreview          = "file:preview"           // 文件缩略图/首页预览生成任务
+	TypeMemoryExtract        = "memory:extract"         // 对话记忆抽取任务
+	TypeGraphCommunityBuild  = "graph:community_build"  // 知识图谱社区报告构建任务
+	TypeChunkImport          = "chunk:import"           // 预分块/预向量化数据批量导入任务
+	TypeKBSnapshot           = "kb:snapshot"            // 知识库快照任务
+	TypeKBRestore            = "kb:restore"             // 知识库快照恢复任务
+	TypeDuplicateAnalysis    = "kb:duplicate_analysis"  // 知识库重复内容分析任务
+	TypeAutoTag              = "knowledge:auto_tag"     // 文档自动打标签任务
+	TypeEvalRun              = "eval:run"               // 检索评测运行任务
+	TypeExperimentShadow     = "experiment:shadow"      // 影子实验回答与评判任务
 )
 
 // ExtractChunkPayload represents the extract chunk task payload
@@ -85,6 +105,9 @@ type DocumentProcessPayload struct {
 	// retried spans overwrite the previous attempt's row rather than
 	// fan out into a new attempt for every retry.
 	Attempt int `json:"attempt,omitempty"`
+	// VersionID is set when the task indexes a new file version of an
+	// existing knowledge: unchanged chunks are kept instead of re-embedded.
+	VersionID string `json:"version_id,omitempty"`
 }
 
 // FAQImportPayload represents the FAQ import task payload (including dry run mode)
@@ -144,6 +167,16 @@ type QuestionGenerationPayload struct {
 	// knowledge. Empty when the batch is at a document boundary.
 	PrevChunkID string `json:"prev_chunk_id,omitempty"`
 	NextChunkID string `json:"next_chunk_id,omitempty"`
+	// ModelID is the chat model generating the questions
+	// (QuestionGenerationConfig.ModelID). Empty falls back to the knowledge
+	// base's summary model, which is also what tasks queued before this
+	// field shipped use.
+	ModelID string `json:"model_id,omitempty"`
+	// Regenerate marks tasks enqueued by an explicit regeneration outside a
+	// parse attempt. They replace the questions of their chunks but must not
+	// drain the knowledge's pending subtask counter, which belongs to the
+	// parse that created the chunks.
+	Regenerate bool `json:"regenerate,omitempty"`
 }
 
 // SummaryGenerationPayload represents the summary generation task payload
@@ -183,6 +216,36 @@ type IndexDeletePayload struct {
 	VectorStoreID *string `json:"vector_store_id,omitempty"`
 }
 
+// GlossaryBuildPayload represents the glossary build task payload
+type GlossaryBuildPayload struct {
+	TracingContext
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	// MaxTerms caps the number of terms sent to the LLM; 0 uses the default.
+	MaxTerms int    `json:"max_terms,omitempty"`
+	Language string `json:"language,omitempty"`
+}
+
+// GraphCommunityBuildPayload represents the graph community build task payload
+type GraphCommunityBuildPayload struct {
+	TracingContext
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	Language        string `json:"language,omitempty"`
+}
+
+// IndexMigrationPayload represents the index migration task payload
+type IndexMigrationPayload struct {
+	TracingContext
+	TenantID        uint64 `json:"tenant_id"`
+	KnowledgeBaseID string `json:"knowledge_base_id"`
+	// Generation is the index generation reserved for this migration.
+	Generation       int64  `json:"generation"`
+	Rechunk          bool   `json:"rechunk,omitempty"`
+	EmbeddingModelID string `json:"embedding_model_id,omitempty"`
+	Language         string `json:"language,omitempty"`
+}
+
 // KBDeletePayload represents the knowledge base delete task payload
 type KBDeletePayload struct {
 	TracingContext
@@ -219,6 +282,11 @@ type KnowledgeMovePayload struct {
 	SourceKBID   string   `json:"source_kb_id"`
 	TargetKBID   string   `json:"target_kb_id"`
 	Mode         string   `json:"mode"` // "reuse_vectors" or "reparse"
+	// Merge moves every knowledge of the source KB; KnowledgeIDs is ignored
+	Merge bool `json:"merge,omitempty"`
+	// Dedupe deletes instead of moves the knowledge whose file hash already
+	// exists in the target KB; always on for merges
+	Dedupe bool `json:"dedupe,omitempty"`
 }
 
 // KnowledgeMoveProgress represents the progress of a knowledge move task
@@ -231,6 +299,7 @@ type KnowledgeMoveProgress struct {
 	Total      int               `json:"total"`      // 总知识数
 	Processed  int               `json:"processed"`  // 已处理数
 	Failed     int               `json:"failed"`     // 失败数
+	Duplicates int               `json:"duplicates"` // 与目标知识库重复而删除的数量
 	Message    string            `json:"message"`    // 状态消息
 	Error      string            `json:"error"`      // 错误信息
 	CreatedAt  int64             `json:"created_at"` // 任务创建时间
@@ -282,6 +351,38 @@ type KnowledgePostProcessPayload struct {
 	Attempt         int    `json:"attempt,omitempty"`
 }
 
+// FilePreviewPayload represents the file preview generation task payload.
+type FilePreviewPayload struct {
+	TracingContext
+	TenantID    uint64 `json:"tenant_id"`
+	KnowledgeID string `json:"knowledge_id"`
+}
+
+// MemoryExtractPayload represents the memory extraction task payload: one
+// chat turn to be stored as a memory episode.
+type MemoryExtractPayload struct {
+	TracingContext
+	TenantID  uint64 `json:"tenant_id"`
+	UserID    string `json:"user_id"`
+	SessionID string `json:"session_id"`
+	// TurnID identifies the turn within the session, typically the user
+	// message ID; a turn is extracted at most once.
+	TurnID string `json:"turn_id"`
+	Query  string `json:"query"`
+	Answer string `json:"answer"`
+	// Trigger is the memory policy the turn was queued under. Empty and
+	// MemoryTriggerEveryTurn store Query and Answer as an episode, and
+	// MemoryTriggerMemorable only when the chat model judges them worth
+	// remembering. MemoryTriggerEveryNTurns and MemoryTriggerSessionEnd
+	// store the session's latest Turns turns as one episode instead, or as
+	// many as fit when Turns is 0.
+	Trigger MemoryTrigger `json:"trigger,omitempty"`
+	Turns   int           `json:"turns,omitempty"`
+	// SummaryOnly updates the session's rolling summary without storing
+	// the turn, for policies under which this turn is not remembered.
+	SummaryOnly bool `json:"summary_only,omitempty"`
+}
+
 // KBCloneTaskStatus represents the status of a knowledge base clone task
 type KBCloneTaskStatus string
 
@@ -1,10 +1,12 @@
 package types
 
 import (
+	"context"
 	"database/sql/driver"
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"strings"
 	"time"
@@ -90,8 +92,15 @@ type Tenant struct {
 	Name string `yaml:"name"                json:"name"`
 	// Description
 	Description string `yaml:"description"         json:"description"`
-	// API key
+	// APIKey is the legacy tenant API key. It acts with the full tenant API
+	// key role and is only returned to the tenant's signed-in Owners, see
+	// RedactedFor.
+	//
+	// Deprecated: issue managed API keys (/api-keys) instead; they carry
+	// scopes, a capped role, knowledge base limits and an expiry.
 	APIKey string `yaml:"api_key"             json:"api_key"`
+	// Tenant role of requests authenticated with the API key, see EffectiveAPIKeyRole
+	APIKeyRole TenantRole `yaml:"api_key_role"        json:"api_key_role"        gorm:"type:varchar(32);not null;default:'admin'"`
 	// Status
 	Status string `yaml:"status"              json:"status"              gorm:"default:'active'"`
 	// Retriever engines
@@ -116,6 +125,14 @@ type Tenant struct {
 	ChatHistoryConfig *ChatHistoryConfig `yaml:"chat_history_config" json:"chat_history_config" gorm:"type:jsonb"`
 	// Retrieval config: global search/retrieval parameters shared by knowledge search and message search
 	RetrievalConfig *RetrievalConfig `yaml:"retrieval_config" json:"retrieval_config" gorm:"type:jsonb"`
+	// Memory extraction config: prompt, entity taxonomy and output schema used to extract conversation memory
+	MemoryExtractionConfig *MemoryExtractionConfig `yaml:"memory_extraction_config" json:"memory_extraction_config" gorm:"type:jsonb"`
+	// Guardrails config: prompt-injection and PII filtering of the user input and retrieved chunks
+	GuardrailsConfig *GuardrailsConfig `yaml:"guardrails_config" json:"guardrails_config" gorm:"type:jsonb"`
+	// Pipeline config: per-stage knowledge QA settings applied over the agent's for every session
+	PipelineConfig *PipelineConfig `yaml:"pipeline_config" json:"pipeline_config" gorm:"type:jsonb"`
+	// Token budget: daily and monthly token limits; model calls are rejected once a limit is reached
+	TokenBudget *TokenBudget `yaml:"token_budget" json:"token_budget" gorm:"type:jsonb"`
 	// Creation time
 	CreatedAt time.Time `yaml:"created_at"          json:"created_at"`
 	// Last updated time
@@ -137,6 +154,34 @@ func (t *Tenant) GetEffectiveEngines() []RetrieverEngineParams {
 	return GetDefaultRetrieverEngines()
 }
 
+// EffectiveAPIKeyRole returns the role granted to API key requests. Tenants
+// that never set one keep the admin role API keys always had.
+func (t *Tenant) EffectiveAPIKeyRole() TenantRole {
+	if t.APIKeyRole.AssignableToAPIKey() {
+		return t.APIKeyRole
+	}
+	return TenantRoleAdmin
+}
+
+// RedactedFor returns the tenant as the caller in ctx may see it: the
+// legacy API key is blanked unless the caller is a signed-in Owner of this
+// tenant. API key requests never get it back, whatever their role or
+// scope, so a read-only key cannot read the full-access legacy one. The
+// tenant itself is not modified.
+func (t *Tenant) RedactedFor(ctx context.Context) *Tenant {
+	if t == nil || t.APIKey == "" {
+		return t
+	}
+	apiKeyAuth, _ := ctx.Value(APIKeyAuthContextKey).(bool)
+	tenantID, _ := ctx.Value(TenantIDContextKey).(uint64)
+	if !apiKeyAuth && tenantID == t.ID && TenantRoleFromContext(ctx) == TenantRoleOwner {
+		return t
+	}
+	redacted := *t
+	redacted.APIKey = ""
+	return &redacted
+}
+
 // BeforeCreate is a hook function that is called before creating a tenant
 func (t *Tenant) BeforeCreate(tx *gorm.DB) error {
 	if t.RetrieverEngines.Engines == nil {
@@ -303,6 +348,10 @@ type ParserEngineConfig struct {
 	PaddleOCRVLCloudModel               string `json:"paddleocr_vl_cloud_model,omitempty"` // e.g. PaddleOCR-VL-1.6
 	PaddleOCRVLCloudUseSealRecognition  *bool  `json:"paddleocr_vl_cloud_use_seal_recognition,omitempty"`
 	PaddleOCRVLCloudUseChartRecognition *bool  `json:"paddleocr_vl_cloud_use_chart_recognition,omitempty"`
+
+	// OCR fallback for scanned PDFs and images without multimodal parsing.
+	OCREndpoint string `json:"ocr_endpoint,omitempty"` // e.g. http://paddleocr:8080
+	OCRProvider string `json:"ocr_provider,omitempty"` // paddleocr (default, PP-StructureV3 /layout-parsing) or http
 }
 
 // ToOverridesMap returns a map suitable for ParserEngineOverrides in parse requests.
@@ -387,6 +436,12 @@ func (c *ParserEngineConfig) ToOverridesMap() map[string]string {
 	if c.PaddleOCRVLCloudUseChartRecognition != nil {
 		m["paddleocr_vl_cloud_use_chart_recognition"] = fmt.Sprintf("%v", *c.PaddleOCRVLCloudUseChartRecognition)
 	}
+	if c.OCREndpoint != "" {
+		m["ocr_endpoint"] = c.OCREndpoint
+	}
+	if c.OCRProvider != "" {
+		m["ocr_provider"] = c.OCRProvider
+	}
 	if len(m) == 0 {
 		return nil
 	}
@@ -413,10 +468,10 @@ func (c *ParserEngineConfig) Scan(value interface{}) error {
 	return json.Unmarshal(b, c)
 }
 
-// StorageEngineConfig holds tenant-level storage engine parameters for Local, MinIO, COS, TOS, S3, OSS, KS3, and OBS.
+// StorageEngineConfig holds tenant-level storage engine parameters for Local, MinIO, COS, TOS, S3, OSS, KS3, OBS, and GCS.
 // Knowledge bases select which provider to use; parameters are read from here.
 type StorageEngineConfig struct {
-	DefaultProvider string             `json:"default_provider"` // "local", "minio", "cos", "tos", "s3", "oss", "ks3", "obs"
+	DefaultProvider string             `json:"default_provider"` // "local", "minio", "cos", "tos", "s3", "oss", "ks3", "obs", "gcs"
 	Local           *LocalEngineConfig `json:"local,omitempty"`
 	MinIO           *MinIOEngineConfig `json:"minio,omitempty"`
 	COS             *COSEngineConfig   `json:"cos,omitempty"`
@@ -425,6 +480,118 @@ type StorageEngineConfig struct {
 	OSS             *OSSEngineConfig   `json:"oss,omitempty"`
 	KS3             *KS3EngineConfig   `json:"ks3,omitempty"`
 	OBS             *OBSEngineConfig   `json:"obs,omitempty"`
+	GCS             *GCSEngineConfig   `json:"gcs,omitempty"`
+	// Encryption applies server-side encryption to objects stored through
+	// any provider that supports it (MinIO, S3, TOS).
+	Encryption *StorageEncryptionConfig `json:"encryption,omitempty"`
+	// TempRetentionHours is how long temp files such as exports are kept
+	// before the cleanup job deletes them. 0 uses TEMP_FILE_RETENTION_HOURS.
+	TempRetentionHours int `json:"temp_retention_hours,omitempty"`
+	// UploadScan controls malware scanning of uploaded files. Scanning only
+	// happens when the server has a scanner configured (FILE_SCAN_PROVIDER).
+	UploadScan *UploadScanConfig `json:"upload_scan,omitempty"`
+	// FileURL shapes the download links handed out for stored files, e.g.
+	// in chat replies sent to IM channels.
+	FileURL *FileURLConfig `json:"file_url,omitempty"`
+}
+
+// MaxFileURLExpirySeconds caps presigned link lifetimes at seven days, the
+// longest most object stores accept for signed URLs.
+const MaxFileURLExpirySeconds = 7 * 24 * 3600
+
+// FileURLConfig is a tenant's preference for file download links.
+type FileURLConfig struct {
+	// ExpirySeconds is how long presigned links stay valid. 0 uses
+	// FILE_URL_EXPIRY_SECONDS, or the provider's default (24h, 2h for local
+	// storage served through APP_EXTERNAL_URL).
+	ExpirySeconds int `json:"expiry_seconds,omitempty"`
+	// CDNDomain replaces the scheme and host of download links, e.g.
+	// "https://cdn.example.com". Links are still signed for the origin, so
+	// the CDN must forward path and query string to it unchanged.
+	CDNDomain string `json:"cdn_domain,omitempty"`
+}
+
+// Validate checks the expiry range and the CDN domain.
+func (c *FileURLConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	if c.ExpirySeconds < 0 || c.ExpirySeconds > MaxFileURLExpirySeconds {
+		return fmt.Errorf("file_url.expiry_seconds must be between 0 and %d", MaxFileURLExpirySeconds)
+	}
+	if c.CDNDomain == "" {
+		return nil
+	}
+	u, err := url.Parse(c.CDNDomain)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+		strings.Trim(u.Path, "/") != "" || u.RawQuery != "" {
+		return fmt.Errorf("file_url.cdn_domain must be an http(s) origin such as https://cdn.example.com")
+	}
+	return nil
+}
+
+// Actions for UploadScanConfig.Action.
+const (
+	// UploadScanActionReject refuses infected uploads.
+	UploadScanActionReject = "reject"
+	// UploadScanActionQuarantine stores infected uploads as quarantined
+	// knowledge that is never parsed.
+	UploadScanActionQuarantine = "quarantine"
+)
+
+// UploadScanConfig is a tenant's upload scanning preference.
+type UploadScanConfig struct {
+	// Enabled turns scanning off for the tenant when false. Unset means on.
+	Enabled *bool `json:"enabled,omitempty"`
+	// Action taken on infected uploads; empty uses FILE_SCAN_ACTION.
+	Action string `json:"action,omitempty"`
+}
+
+// IsEnabled reports whether the tenant's uploads are scanned. A nil config
+// keeps scanning on.
+func (c *UploadScanConfig) IsEnabled() bool {
+	return c == nil || c.Enabled == nil || *c.Enabled
+}
+
+// Validate checks the action.
+func (c *UploadScanConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	switch c.Action {
+	case "", UploadScanActionReject, UploadScanActionQuarantine:
+		return nil
+	default:
+		return fmt.Errorf("upload_scan.action must be one of: reject, quarantine")
+	}
+}
+
+// ConfiguredProviders returns the providers that have a config block, in a
+// fixed order.
+func (c *StorageEngineConfig) ConfiguredProviders() []string {
+	if c == nil {
+		return nil
+	}
+	var providers []string
+	for _, p := range []struct {
+		name       string
+		configured bool
+	}{
+		{"local", c.Local != nil},
+		{"minio", c.MinIO != nil},
+		{"cos", c.COS != nil},
+		{"tos", c.TOS != nil},
+		{"s3", c.S3 != nil},
+		{"oss", c.OSS != nil},
+		{"ks3", c.KS3 != nil},
+		{"obs", c.OBS != nil},
+		{"gcs", c.GCS != nil},
+	} {
+		if p.configured {
+			providers = append(providers, p.name)
+		}
+	}
+	return providers
 }
 
 // LocalEngineConfig is for local file system storage (single-machine deployment only).
@@ -510,6 +677,74 @@ type OBSEngineConfig struct {
 	UseSSL     bool   `json:"use_ssl"`
 }
 
+// GCSEngineConfig is for Google Cloud Storage. CredentialsJSON is a service
+// account key file; tenants must supply one, since workload identity would
+// grant them the server's own access. Workload identity is available to the
+// env-configured global storage only.
+type GCSEngineConfig struct {
+	ProjectID       string `json:"project_id"`
+	Location        string `json:"location"`
+	BucketName      string `json:"bucket_name"`
+	PathPrefix      string `json:"path_prefix"`
+	CredentialsJSON string `json:"credentials_json"`
+}
+
+// Server-side encryption modes for StorageEncryptionConfig.Mode.
+const (
+	// StorageEncryptionNone stores objects with the bucket's default settings.
+	StorageEncryptionNone = ""
+	// StorageEncryptionSSES3 encrypts objects with keys managed by the storage
+	// service (SSE-S3 on S3/MinIO, SSE-TOS on TOS).
+	StorageEncryptionSSES3 = "sse-s3"
+	// StorageEncryptionSSEKMS encrypts objects with a customer-managed KMS key.
+	StorageEncryptionSSEKMS = "sse-kms"
+)
+
+// StorageEncryptionConfig selects server-side encryption for stored files.
+type StorageEncryptionConfig struct {
+	Mode string `json:"mode"`
+	// KMSKeyID is the KMS key (ID or ARN) used by sse-kms. Rotating it only
+	// affects new uploads until existing files are re-encrypted.
+	KMSKeyID string `json:"kms_key_id,omitempty"`
+}
+
+// Enabled reports whether objects are encrypted server-side.
+func (c *StorageEncryptionConfig) Enabled() bool {
+	return c != nil && c.Mode != StorageEncryptionNone
+}
+
+// KeyID identifies the key new objects are encrypted with, as recorded in
+// Knowledge.EncryptionKeyID: the KMS key for sse-kms, the mode for sse-s3,
+// and "" when encryption is off.
+func (c *StorageEncryptionConfig) KeyID() string {
+	switch {
+	case !c.Enabled():
+		return ""
+	case c.Mode == StorageEncryptionSSEKMS:
+		return c.KMSKeyID
+	default:
+		return c.Mode
+	}
+}
+
+// Validate checks the mode and that sse-kms names a key.
+func (c *StorageEncryptionConfig) Validate() error {
+	if c == nil {
+		return nil
+	}
+	switch c.Mode {
+	case StorageEncryptionNone, StorageEncryptionSSES3:
+		return nil
+	case StorageEncryptionSSEKMS:
+		if strings.TrimSpace(c.KMSKeyID) == "" {
+			return fmt.Errorf("kms_key_id is required for sse-kms encryption")
+		}
+		return nil
+	default:
+		return fmt.Errorf("encryption mode must be one of: sse-s3, sse-kms")
+	}
+}
+
 // Value implements the driver.Valuer interface for StorageEngineConfig
 func (c *StorageEngineConfig) Value() (driver.Value, error) {
 	if c == nil {
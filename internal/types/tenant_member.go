@@ -48,6 +48,13 @@ func (r TenantRole) IsValid() bool {
 	return ok
 }
 
+// AssignableToAPIKey reports whether the tenant API key may carry r. The
+// owner role stays with humans: an API key lives in scripts and CI where it
+// leaks far more easily than a login.
+func (r TenantRole) AssignableToAPIKey() bool {
+	return r.IsValid() && r != TenantRoleOwner
+}
+
 // Level returns the numeric privilege level of the role. Unknown roles
 // return 0, which is strictly less than any defined role.
 func (r TenantRole) Level() int {
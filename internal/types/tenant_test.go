@@ -1,6 +1,7 @@
 package types
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -18,3 +19,45 @@ func TestRetrieverEngineMappingIncludesTencentVectorDBHybridCapabilities(t *test
 		RetrieverEngineType: TencentVectorDBRetrieverEngineType,
 	})
 }
+
+func TestFileURLConfigValidate(t *testing.T) {
+	var nilCfg *FileURLConfig
+	assert.NoError(t, nilCfg.Validate())
+	assert.NoError(t, (&FileURLConfig{ExpirySeconds: 3600, CDNDomain: "https://cdn.example.com"}).Validate())
+	assert.NoError(t, (&FileURLConfig{CDNDomain: "http://cdn.example.com:8080/"}).Validate())
+
+	assert.Error(t, (&FileURLConfig{ExpirySeconds: -1}).Validate())
+	assert.Error(t, (&FileURLConfig{ExpirySeconds: MaxFileURLExpirySeconds + 1}).Validate())
+	assert.Error(t, (&FileURLConfig{CDNDomain: "cdn.example.com"}).Validate())
+	assert.Error(t, (&FileURLConfig{CDNDomain: "https://cdn.example.com/files"}).Validate())
+}
+
+func TestTenantEffectiveAPIKeyRole(t *testing.T) {
+	assert.Equal(t, TenantRoleAdmin, (&Tenant{}).EffectiveAPIKeyRole(), "tenants without a role keep admin")
+	assert.Equal(t, TenantRoleViewer, (&Tenant{APIKeyRole: TenantRoleViewer}).EffectiveAPIKeyRole())
+	assert.Equal(t, TenantRoleContributor, (&Tenant{APIKeyRole: TenantRoleContributor}).EffectiveAPIKeyRole())
+	assert.Equal(t, TenantRoleAdmin, (&Tenant{APIKeyRole: TenantRoleOwner}).EffectiveAPIKeyRole(),
+		"an API key never acts as owner")
+	assert.Equal(t, TenantRoleAdmin, (&Tenant{APIKeyRole: "root"}).EffectiveAPIKeyRole())
+}
+
+func TestTenantRedactedFor(t *testing.T) {
+	tenant := &Tenant{ID: 7, APIKey: "sk-legacy"}
+	caller := func(tenantID uint64, role TenantRole, apiKey bool) context.Context {
+		ctx := context.WithValue(context.Background(), TenantIDContextKey, tenantID)
+		ctx = context.WithValue(ctx, TenantRoleContextKey, role)
+		if apiKey {
+			ctx = context.WithValue(ctx, APIKeyAuthContextKey, true)
+		}
+		return ctx
+	}
+
+	assert.Equal(t, "sk-legacy", tenant.RedactedFor(caller(7, TenantRoleOwner, false)).APIKey)
+	assert.Empty(t, tenant.RedactedFor(caller(7, TenantRoleAdmin, false)).APIKey, "non-owners")
+	assert.Empty(t, tenant.RedactedFor(caller(7, TenantRoleViewer, true)).APIKey, "API key requests")
+	assert.Empty(t, tenant.RedactedFor(caller(7, TenantRoleOwner, true)).APIKey, "API key requests never act as owner")
+	assert.Empty(t, tenant.RedactedFor(caller(8, TenantRoleOwner, false)).APIKey, "owners of another tenant")
+	assert.Empty(t, tenant.RedactedFor(context.Background()).APIKey, "unauthenticated")
+	assert.Equal(t, "sk-legacy", tenant.APIKey, "the tenant itself is not modified")
+	assert.Nil(t, (*Tenant)(nil).RedactedFor(context.Background()))
+}
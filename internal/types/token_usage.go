@@ -0,0 +1,196 @@
+package types
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TokenUsageDayLayout is the format of TokenUsageDaily.Day and of the date
+// range of usage reports.
+const TokenUsageDayLayout = "2006-01-02"
+
+// ErrTokenBudgetExceeded is returned for model calls of a tenant that has
+// used up its daily or monthly token budget.
+var ErrTokenBudgetExceeded = errors.New("token budget exceeded")
+
+// TokenUsageKind is the kind of model call a usage event comes from
+type TokenUsageKind string
+
+const (
+	TokenUsageKindChat      TokenUsageKind = "chat"
+	TokenUsageKindEmbedding TokenUsageKind = "embedding"
+)
+
+// TokenUsageEvent is the token usage of a single model call. Estimated is
+// set when the provider did not report usage and the counts were estimated
+// from the text lengths.
+type TokenUsageEvent struct {
+	Kind             TokenUsageKind
+	ModelID          string
+	ModelName        string
+	PromptTokens     int64
+	CompletionTokens int64
+	EmbeddingTokens  int64
+	Estimated        bool
+}
+
+// TokenUsageDaily aggregates the token usage of a tenant per day, model and
+// session. Calls made outside a session (indexing, summaries of knowledge,
+// model tests) have an empty SessionID.
+type TokenUsageDaily struct {
+	// Tenant ID
+	TenantID uint64 `json:"tenant_id" gorm:"primaryKey"`
+	// Day in UTC, formatted as TokenUsageDayLayout
+	Day string `json:"day" gorm:"type:varchar(10);primaryKey"`
+	// Model ID; empty for models built outside the model registry
+	ModelID string `json:"model_id" gorm:"type:varchar(64);primaryKey"`
+	// Session ID; empty for calls outside a session
+	SessionID string `json:"session_id" gorm:"type:varchar(36);primaryKey"`
+	// Model name at the time of the last call
+	ModelName string `json:"model_name" gorm:"type:varchar(255)"`
+	// Number of model calls
+	Requests int64 `json:"requests"`
+	// Number of model calls whose usage was estimated
+	EstimatedRequests int64 `json:"estimated_requests"`
+	// Prompt tokens of chat calls
+	PromptTokens int64 `json:"prompt_tokens"`
+	// Completion tokens of chat calls
+	CompletionTokens int64 `json:"completion_tokens"`
+	// Input tokens of embedding calls
+	EmbeddingTokens int64 `json:"embedding_tokens"`
+	// Last updated time
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name of TokenUsageDaily
+func (TokenUsageDaily) TableName() string {
+	return "token_usage_daily"
+}
+
+// TokenUsageGroupBy is the dimension a usage report is broken down by
+type TokenUsageGroupBy string
+
+const (
+	TokenUsageGroupByDay     TokenUsageGroupBy = "day"
+	TokenUsageGroupByModel   TokenUsageGroupBy = "model"
+	TokenUsageGroupBySession TokenUsageGroupBy = "session"
+)
+
+// IsValid reports whether g is a supported grouping
+func (g TokenUsageGroupBy) IsValid() bool {
+	switch g {
+	case TokenUsageGroupByDay, TokenUsageGroupByModel, TokenUsageGroupBySession:
+		return true
+	default:
+		return false
+	}
+}
+
+// TokenUsageQuery selects the usage of a tenant for a report. StartDay and
+// EndDay are inclusive and formatted as TokenUsageDayLayout.
+type TokenUsageQuery struct {
+	TenantID  uint64
+	StartDay  string
+	EndDay    string
+	GroupBy   TokenUsageGroupBy
+	ModelID   string
+	SessionID string
+}
+
+// TokenUsageSummary is the usage of one group of a report. Key is the day,
+// model ID or session ID the group stands for.
+type TokenUsageSummary struct {
+	Key               string `json:"key"`
+	ModelName         string `json:"model_name,omitempty"`
+	Requests          int64  `json:"requests"`
+	EstimatedRequests int64  `json:"estimated_requests"`
+	PromptTokens      int64  `json:"prompt_tokens"`
+	CompletionTokens  int64  `json:"completion_tokens"`
+	EmbeddingTokens   int64  `json:"embedding_tokens"`
+	TotalTokens       int64  `json:"total_tokens"`
+}
+
+// Add adds the counts of o to s
+func (s *TokenUsageSummary) Add(o TokenUsageSummary) {
+	s.Requests += o.Requests
+	s.EstimatedRequests += o.EstimatedRequests
+	s.PromptTokens += o.PromptTokens
+	s.CompletionTokens += o.CompletionTokens
+	s.EmbeddingTokens += o.EmbeddingTokens
+	s.TotalTokens += o.TotalTokens
+}
+
+// TokenUsageReport is the usage of a tenant over a date range
+type TokenUsageReport struct {
+	StartDate string              `json:"start_date"`
+	EndDate   string              `json:"end_date"`
+	GroupBy   TokenUsageGroupBy   `json:"group_by"`
+	Total     TokenUsageSummary   `json:"total"`
+	Items     []TokenUsageSummary `json:"items"`
+}
+
+// TokenBudget limits the tokens (prompt, completion and embedding) a tenant
+// may use per UTC day and per UTC calendar month. A zero limit is no limit.
+type TokenBudget struct {
+	DailyTokens   int64 `json:"daily_tokens"`
+	MonthlyTokens int64 `json:"monthly_tokens"`
+}
+
+// Enabled reports whether b limits anything
+func (b *TokenBudget) Enabled() bool {
+	return b != nil && (b.DailyTokens > 0 || b.MonthlyTokens > 0)
+}
+
+// Exceeds reports whether the used tokens reach a limit of b
+func (b *TokenBudget) Exceeds(dailyUsed, monthlyUsed int64) bool {
+	if b == nil {
+		return false
+	}
+	return (b.DailyTokens > 0 && dailyUsed >= b.DailyTokens) ||
+		(b.MonthlyTokens > 0 && monthlyUsed >= b.MonthlyTokens)
+}
+
+// Validate checks the limits are not negative
+func (b *TokenBudget) Validate() error {
+	if b == nil {
+		return nil
+	}
+	if b.DailyTokens < 0 || b.MonthlyTokens < 0 {
+		return fmt.Errorf("token limits must not be negative")
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface for database serialization
+func (b TokenBudget) Value() (driver.Value, error) {
+	return json.Marshal(b)
+}
+
+// Scan implements the sql.Scanner interface for database deserialization
+func (b *TokenBudget) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return nil
+	}
+	return json.Unmarshal(data, b)
+}
+
+// TokenBudgetStatus is a tenant's budget together with the tokens used
+// today and this month.
+type TokenBudgetStatus struct {
+	Budget      TokenBudget `json:"budget"`
+	DailyUsed   int64       `json:"daily_used"`
+	MonthlyUsed int64       `json:"monthly_used"`
+	Exceeded    bool        `json:"exceeded"`
+}
@@ -35,6 +35,9 @@ type TracingContext struct {
 	LangfuseUserID string `json:"lf_user_id,omitempty"`
 	// LangfuseSessionID preserves the sessionId for the same reason.
 	LangfuseSessionID string `json:"lf_session_id,omitempty"`
+	// TraceParent is the W3C traceparent of the OpenTelemetry span that
+	// enqueued the task; the worker continues that trace from it.
+	TraceParent string `json:"otel_traceparent,omitempty"`
 }
 
 // SetLangfuseTracing overwrites the embedded TracingContext. Method is
@@ -98,6 +98,9 @@ type User struct {
 	// Stored as JSON (jsonb on Postgres, TEXT on SQLite) via the
 	// driver.Valuer / sql.Scanner methods on UserPreferences.
 	Preferences UserPreferences `json:"preferences" gorm:"type:jsonb;not null;default:'{}'"`
+	// External groups from the SSO provider's group claim, refreshed on
+	// every SSO login. Document ACLs match them as "sso:<group>".
+	SSOGroups StringArray `json:"sso_groups,omitempty" gorm:"type:json"`
 	// Creation time of the user
 	CreatedAt time.Time `json:"created_at"`
 	// Last updated time of the user
@@ -173,6 +176,7 @@ type OIDCUserInfo struct {
 	Subject  string                 `json:"subject,omitempty"`
 	Username string                 `json:"username,omitempty"`
 	Email    string                 `json:"email,omitempty"`
+	Groups   []string               `json:"groups,omitempty"`
 	Claims   map[string]interface{} `json:"claims,omitempty"`
 }
 
@@ -9,6 +9,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/Tencent/WeKnora/internal/errors"
 	"github.com/Tencent/WeKnora/internal/utils"
@@ -265,8 +266,52 @@ type IndexConfig struct {
 	HNSWEFConstruction int    `yaml:"hnsw_ef_construction" json:"hnsw_ef_construction,omitempty"` // OpenSearch: HNSW index-build candidate list size
 	HNSWEFSearch       int    `yaml:"hnsw_ef_search" json:"hnsw_ef_search,omitempty"`             // OpenSearch: HNSW search candidate list size (faiss; lucene reads at query time)
 	KNNEngine          string `yaml:"knn_engine" json:"knn_engine,omitempty"`                     // OpenSearch: k-NN backend ("lucene" | "faiss")
+
+	// --- Full-text analyzer fields ---
+	// Applied to the content field when a collection is created. Existing
+	// collections keep the analyzer they were built with until reindexed.
+	AnalyzerType       string   `yaml:"analyzer_type" json:"analyzer_type,omitempty"`             // Milvus: content tokenizer ("standard" | "jieba" | "icu")
+	AnalyzerStopWords  []string `yaml:"analyzer_stop_words" json:"analyzer_stop_words,omitempty"` // Milvus: extra stop words dropped before BM25 scoring
+	AnalyzerDictionary []string `yaml:"analyzer_dictionary" json:"analyzer_dictionary,omitempty"` // Milvus: custom jieba dictionary terms (domain vocabulary)
+
+	// --- Payload storage fields ---
+	ContentStorage string `yaml:"content_storage" json:"content_storage,omitempty"` // Milvus: "full" (default) | "reference" (chunk ID only, content hydrated from the chunk repository)
+
+	// --- Collection load fields ---
+	LoadPolicy string `yaml:"load_policy" json:"load_policy,omitempty"` // Milvus: "eager" (default) | "lazy" | "mmap"
 }
 
+// Analyzer types accepted in IndexConfig.AnalyzerType.
+const (
+	AnalyzerTypeStandard = "standard"
+	AnalyzerTypeJieba    = "jieba"
+	AnalyzerTypeICU      = "icu"
+)
+
+// Content storage modes accepted in IndexConfig.ContentStorage.
+//
+// ContentStorageReference keeps the vector store row small by leaving the
+// content field empty; search results are already assembled from the chunk
+// repository, so only the engine's own keyword (BM25) retrieval is lost.
+const (
+	ContentStorageFull      = "full"
+	ContentStorageReference = "reference"
+)
+
+// Collection load policies accepted in IndexConfig.LoadPolicy.
+//
+// LoadPolicyEager loads existing collections in the background at startup
+// and awaits the load of a new collection before first use.
+// LoadPolicyLazy skips the warm-up and loads collections asynchronously on
+// first use; writes proceed at once and searches wait for the load.
+// LoadPolicyMmap behaves like eager but creates collections memory-mapped,
+// trading some search latency for faster loads and a smaller memory footprint.
+const (
+	LoadPolicyEager = "eager"
+	LoadPolicyLazy  = "lazy"
+	LoadPolicyMmap  = "mmap"
+)
+
 // Value implements the driver.Valuer interface.
 func (c IndexConfig) Value() (driver.Value, error) {
 	return json.Marshal(c)
@@ -409,6 +454,30 @@ func (c *IndexConfig) GetReplicationNum(def int) int {
 	return def
 }
 
+// GetAnalyzerType returns the configured analyzer_type in lower case, or def if unset.
+func (c *IndexConfig) GetAnalyzerType(def string) string {
+	if c != nil && c.AnalyzerType != "" {
+		return strings.ToLower(c.AnalyzerType)
+	}
+	return def
+}
+
+// GetContentStorage returns the configured content_storage in lower case, or def if unset.
+func (c *IndexConfig) GetContentStorage(def string) string {
+	if c != nil && c.ContentStorage != "" {
+		return strings.ToLower(c.ContentStorage)
+	}
+	return strings.ToLower(strings.TrimSpace(def))
+}
+
+// GetLoadPolicy returns the configured load_policy in lower case, or def if unset.
+func (c *IndexConfig) GetLoadPolicy(def string) string {
+	if c != nil && c.LoadPolicy != "" {
+		return strings.ToLower(c.LoadPolicy)
+	}
+	return strings.ToLower(strings.TrimSpace(def))
+}
+
 // ---------------------------------------------------------------------------
 // IndexConfig — resolve helpers (for Repository layer, with env var fallback)
 // ---------------------------------------------------------------------------
@@ -464,6 +533,11 @@ const (
 	maxShards = 64
 	// maxReplicas is the upper bound for replication-related configuration values.
 	maxReplicas = 10
+	// maxAnalyzerTerms bounds stop-word and dictionary lists; both are stored
+	// in the collection schema and shipped to Milvus on every create.
+	maxAnalyzerTerms = 10000
+	// maxAnalyzerTermLen bounds a single stop word or dictionary entry.
+	maxAnalyzerTermLen = 64
 )
 
 // validIndexNamePattern restricts index/collection names to safe characters.
@@ -516,6 +590,46 @@ func ValidateIndexConfig(ic IndexConfig) error {
 		return errors.NewValidationError(fmt.Sprintf("replication_num must be between 0 and %d", maxReplicas))
 	}
 
+	// Validate analyzer fields
+	switch ic.GetAnalyzerType("") {
+	case "", AnalyzerTypeStandard, AnalyzerTypeJieba, AnalyzerTypeICU:
+	default:
+		return errors.NewValidationError("analyzer_type must be one of: standard, jieba, icu")
+	}
+	if len(ic.AnalyzerDictionary) > 0 && ic.GetAnalyzerType("") != AnalyzerTypeJieba {
+		return errors.NewValidationError("analyzer_dictionary is only supported with analyzer_type jieba")
+	}
+	switch ic.GetContentStorage("") {
+	case "", ContentStorageFull, ContentStorageReference:
+	default:
+		return errors.NewValidationError("content_storage must be one of: full, reference")
+	}
+	switch ic.GetLoadPolicy("") {
+	case "", LoadPolicyEager, LoadPolicyLazy, LoadPolicyMmap:
+	default:
+		return errors.NewValidationError("load_policy must be one of: eager, lazy, mmap")
+	}
+	if err := validateAnalyzerTerms("analyzer_stop_words", ic.AnalyzerStopWords); err != nil {
+		return err
+	}
+	if err := validateAnalyzerTerms("analyzer_dictionary", ic.AnalyzerDictionary); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// validateAnalyzerTerms bounds a stop-word or dictionary list.
+func validateAnalyzerTerms(field string, terms []string) error {
+	if len(terms) > maxAnalyzerTerms {
+		return errors.NewValidationError(fmt.Sprintf("%s must contain at most %d entries", field, maxAnalyzerTerms))
+	}
+	for _, term := range terms {
+		if strings.TrimSpace(term) == "" || utf8.RuneCountInString(term) > maxAnalyzerTermLen {
+			return errors.NewValidationError(
+				fmt.Sprintf("%s entries must be non-empty and at most %d characters", field, maxAnalyzerTermLen))
+		}
+	}
 	return nil
 }
 
@@ -712,6 +826,12 @@ func GetVectorStoreTypes() []VectorStoreTypeInfo {
 				{Name: "collection_name", Type: "string", Required: false, Description: "Collection Name", Default: "weknora_embeddings"},
 				{Name: "shards_num", Type: "number", Required: false, Description: "Shards (write parallelism)", Default: 1},
 				{Name: "replica_number", Type: "number", Required: false, Description: "In-memory Replicas (read HA)", Default: 1},
+				{Name: "analyzer_type", Type: "string", Required: false, Description: "Full-text Analyzer", Default: AnalyzerTypeStandard,
+					Immutable: true, Enum: []string{AnalyzerTypeStandard, AnalyzerTypeJieba, AnalyzerTypeICU}},
+				{Name: "content_storage", Type: "string", Required: false, Description: "Content Storage (reference disables keyword search)", Default: ContentStorageFull,
+					Immutable: true, Enum: []string{ContentStorageFull, ContentStorageReference}},
+				{Name: "load_policy", Type: "string", Required: false, Description: "Collection Load Policy (mmap applies to new collections)", Default: LoadPolicyEager,
+					Enum: []string{LoadPolicyEager, LoadPolicyLazy, LoadPolicyMmap}},
 			},
 		},
 		{
@@ -0,0 +1,42 @@
+package types
+
+// EngineCapabilities describes what a retrieve engine supports so callers
+// (the frontend, agents) can adapt requests instead of discovering limits
+// through errors. Returned by GET /vector-stores/:id/capabilities.
+type EngineCapabilities struct {
+	EngineType RetrieverEngineType `json:"engine_type"`
+	// RetrieverTypes are the retrieval modes the engine serves.
+	RetrieverTypes []RetrieverType `json:"retriever_types"`
+	// HybridSearch reports that keyword and vector retrieval are both
+	// served by the engine, so one store can answer hybrid searches.
+	HybridSearch bool `json:"hybrid_search"`
+	// FilterOperators lists the operators accepted in structured filter
+	// conditions (see POST /vector-stores/:id/validate-filter). Empty when
+	// the engine takes no structured filters.
+	FilterOperators []string `json:"filter_operators"`
+	// MaxFilterDepth is the deepest and/or nesting a filter condition may
+	// use; 0 when the engine takes no structured filters.
+	MaxFilterDepth int `json:"max_filter_depth"`
+	// PartialUpdate reports that chunk field updates (enabled status, tag)
+	// are written in place rather than by rewriting whole rows.
+	PartialUpdate bool `json:"partial_update"`
+	// MaxTopK is the largest TopK one retrieval returns; 0 when the engine
+	// imposes no limit of its own.
+	MaxTopK int `json:"max_top_k"`
+}
+
+// NewEngineCapabilities fills the fields derived from the engine type and
+// its retriever types; engines set the rest.
+func NewEngineCapabilities(engineType RetrieverEngineType, retrieverTypes []RetrieverType) EngineCapabilities {
+	var keywords, vector bool
+	for _, t := range retrieverTypes {
+		keywords = keywords || t == KeywordsRetrieverType
+		vector = vector || t == VectorRetrieverType
+	}
+	return EngineCapabilities{
+		EngineType:      engineType,
+		RetrieverTypes:  retrieverTypes,
+		HybridSearch:    keywords && vector,
+		FilterOperators: []string{},
+	}
+}
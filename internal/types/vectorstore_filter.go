@@ -0,0 +1,22 @@
+package types
+
+// FilterValidation is the dry-run result of a metadata filter condition,
+// returned by POST /vector-stores/:id/validate-filter. When Valid is false,
+// Issues lists every problem found and Expression/Params are empty.
+type FilterValidation struct {
+	EngineType RetrieverEngineType `json:"engine_type"`
+	Valid      bool                `json:"valid"`
+	// Expression is the engine-native filter expression, with values bound
+	// through Params placeholders rather than inlined.
+	Expression string         `json:"expression,omitempty"`
+	Params     map[string]any `json:"params,omitempty"`
+	Issues     []FilterIssue  `json:"issues,omitempty"`
+}
+
+// FilterIssue describes one problem in a filter condition. Path locates the
+// offending node, e.g. "value[1]" for the second child of the root "and".
+type FilterIssue struct {
+	Path    string `json:"path"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,29 @@
+package types
+
+// Collection load states reported in CollectionLoadState.State.
+const (
+	CollectionLoaded    = "loaded"
+	CollectionLoading   = "loading"
+	CollectionNotLoaded = "not_loaded"
+)
+
+// LoadStateReport describes how far an engine's collections are loaded into
+// memory, returned by GET /vector-stores/:id/load-state.
+type LoadStateReport struct {
+	EngineType RetrieverEngineType `json:"engine_type"`
+	LoadPolicy string              `json:"load_policy"`
+	// Ready is true when every collection is loaded and searches will not
+	// wait on a load.
+	Ready       bool                  `json:"ready"`
+	Collections []CollectionLoadState `json:"collections"`
+}
+
+// CollectionLoadState is the load state of a single collection. Progress
+// is a percentage; Error holds the last failed load attempt, if any.
+type CollectionLoadState struct {
+	Collection string `json:"collection"`
+	Dimension  int    `json:"dimension"`
+	State      string `json:"state"`
+	Progress   int64  `json:"progress"`
+	Error      string `json:"error,omitempty"`
+}
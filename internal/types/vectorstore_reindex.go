@@ -0,0 +1,99 @@
+package types
+
+// ReindexReport summarizes a collection rebuild triggered through
+// POST /vector-stores/:id/reindex.
+type ReindexReport struct {
+	EngineType  RetrieverEngineType       `json:"engine_type"`
+	Collections []CollectionReindexResult `json:"collections"`
+}
+
+// CollectionReindexResult is the outcome for a single per-dimension collection.
+// Error is empty on success; a failed collection keeps its original data.
+type CollectionReindexResult struct {
+	Collection string `json:"collection"`
+	Dimension  int    `json:"dimension"`
+	Rows       int    `json:"rows"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Failed returns the number of collections that could not be rebuilt.
+func (r *ReindexReport) Failed() int {
+	if r == nil {
+		return 0
+	}
+	n := 0
+	for _, c := range r.Collections {
+		if c.Error != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// ModelSpaceMigrationReport summarizes moving rows out of legacy
+// per-dimension collections into per-embedding-model collections, triggered
+// through POST /vector-stores/:id/migrate-model-spaces.
+type ModelSpaceMigrationReport struct {
+	EngineType  RetrieverEngineType         `json:"engine_type"`
+	Collections []ModelSpaceMigrationResult `json:"collections"`
+}
+
+// ModelSpaceMigrationResult is the outcome for a single legacy collection.
+// Remaining counts rows left behind: rows of other tenants' knowledge bases
+// or of knowledge bases without an embedding model. The legacy collection is
+// dropped once it is empty.
+type ModelSpaceMigrationResult struct {
+	Collection string `json:"collection"`
+	Dimension  int    `json:"dimension"`
+	Migrated   int    `json:"migrated"`
+	Remaining  int    `json:"remaining"`
+	Dropped    bool   `json:"dropped"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Failed returns the number of legacy collections whose migration failed.
+func (r *ModelSpaceMigrationReport) Failed() int {
+	if r == nil {
+		return 0
+	}
+	n := 0
+	for _, c := range r.Collections {
+		if c.Error != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// DedupReport summarizes a duplicate-row repair of a vector store, triggered
+// through POST /vector-stores/:id/dedup.
+type DedupReport struct {
+	EngineType  RetrieverEngineType     `json:"engine_type"`
+	Collections []CollectionDedupResult `json:"collections"`
+}
+
+// CollectionDedupResult is the outcome for a single collection. Rekeyed
+// counts rows moved to their deterministic ID (see IndexRowID); Removed
+// counts the duplicates that collapsed as a result.
+type CollectionDedupResult struct {
+	Collection string `json:"collection"`
+	Dimension  int    `json:"dimension"`
+	Scanned    int    `json:"scanned"`
+	Rekeyed    int    `json:"rekeyed"`
+	Removed    int    `json:"removed"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Failed returns the number of collections whose repair failed.
+func (r *DedupReport) Failed() int {
+	if r == nil {
+		return 0
+	}
+	n := 0
+	for _, c := range r.Collections {
+		if c.Error != "" {
+			n++
+		}
+	}
+	return n
+}
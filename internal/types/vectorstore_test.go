@@ -1092,6 +1092,45 @@ func TestValidateIndexConfig(t *testing.T) {
 		ic := IndexConfig{CollectionPrefix: "custom_prefix"}
 		assert.Equal(t, "custom_prefix", ic.GetIndexNameOrDefault(DorisRetrieverEngineType))
 	})
+
+	// --- Analyzer validation ---
+	t.Run("jieba analyzer with dictionary accepted", func(t *testing.T) {
+		ic := IndexConfig{
+			AnalyzerType:       "Jieba",
+			AnalyzerStopWords:  []string{"的", "了"},
+			AnalyzerDictionary: []string{"向量数据库"},
+		}
+		assert.NoError(t, ValidateIndexConfig(ic))
+	})
+
+	t.Run("unknown analyzer_type rejected", func(t *testing.T) {
+		err := ValidateIndexConfig(IndexConfig{AnalyzerType: "ik"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "analyzer_type")
+	})
+
+	t.Run("analyzer_dictionary without jieba rejected", func(t *testing.T) {
+		err := ValidateIndexConfig(IndexConfig{AnalyzerType: AnalyzerTypeICU, AnalyzerDictionary: []string{"term"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "analyzer_dictionary")
+	})
+
+	t.Run("blank stop word rejected", func(t *testing.T) {
+		err := ValidateIndexConfig(IndexConfig{AnalyzerStopWords: []string{" "}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "analyzer_stop_words")
+	})
+
+	// --- Load policy validation ---
+	t.Run("load_policy is case-insensitive", func(t *testing.T) {
+		assert.NoError(t, ValidateIndexConfig(IndexConfig{LoadPolicy: "MMAP"}))
+	})
+
+	t.Run("unknown load_policy rejected", func(t *testing.T) {
+		err := ValidateIndexConfig(IndexConfig{LoadPolicy: "preload"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "load_policy")
+	})
 }
 
 // ---------------------------------------------------------------------------
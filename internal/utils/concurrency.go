@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"errors"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// ForEachLimit calls fn for every item, running at most limit calls at once.
+// A failing call does not stop the others; the errors of all failed calls
+// are joined in item order.
+func ForEachLimit[T any](items []T, limit int, fn func(i int, item T) error) error {
+	var (
+		g    errgroup.Group
+		mu   sync.Mutex
+		errs = make([]error, len(items))
+	)
+	g.SetLimit(limit)
+	for i, item := range items {
+		g.Go(func() error {
+			if err := fn(i, item); err != nil {
+				mu.Lock()
+				errs[i] = err
+				mu.Unlock()
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return errors.Join(errs...)
+}
+
+// DropGuard serialises dropping an emptied shared resource, such as a
+// per-dimension vector collection, against writes to it. Writes to a key
+// share its lock; a drop holds it exclusively and counts again before
+// dropping, so a write racing the delete that emptied the resource is
+// never dropped with it. The zero value is ready to use.
+type DropGuard struct {
+	locks sync.Map // key -> *sync.RWMutex
+}
+
+func (g *DropGuard) lock(key string) *sync.RWMutex {
+	l, _ := g.locks.LoadOrStore(key, &sync.RWMutex{})
+	return l.(*sync.RWMutex)
+}
+
+// Write runs fn while key cannot be dropped.
+func (g *DropGuard) Write(key string, fn func() error) error {
+	l := g.lock(key)
+	l.RLock()
+	defer l.RUnlock()
+	return fn()
+}
+
+// DropIfEmpty calls drop if count reports nothing left under key, with
+// writes to key held off for both calls. It reports whether key was dropped.
+func (g *DropGuard) DropIfEmpty(key string, count func() (int, error), drop func() error) (bool, error) {
+	l := g.lock(key)
+	l.Lock()
+	defer l.Unlock()
+	remaining, err := count()
+	if err != nil || remaining > 0 {
+		return false, err
+	}
+	if err := drop(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForEachLimitBoundsConcurrency(t *testing.T) {
+	items := make([]int, 20)
+	var running, peak, calls atomic.Int32
+	err := ForEachLimit(items, 3, func(int, int) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		calls.Add(1)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(20), calls.Load())
+	assert.Equal(t, int32(3), peak.Load())
+}
+
+func TestForEachLimitJoinsErrors(t *testing.T) {
+	errB := errors.New("collection b is down")
+	errD := errors.New("collection d is down")
+	var calls atomic.Int32
+	err := ForEachLimit([]string{"a", "b", "c", "d"}, 2, func(_ int, name string) error {
+		calls.Add(1)
+		switch name {
+		case "b":
+			return errB
+		case "d":
+			return fmt.Errorf("upsert: %w", errD)
+		}
+		return nil
+	})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, errB)
+	assert.ErrorIs(t, err, errD)
+	assert.Equal(t, "collection b is down\nupsert: collection d is down", err.Error())
+	assert.Equal(t, int32(4), calls.Load(), "a failure does not stop the other items")
+
+	assert.NoError(t, ForEachLimit([]string(nil), 2, func(int, string) error { return errB }))
+}
+
+func TestDropGuardRecountsAfterWrites(t *testing.T) {
+	var g DropGuard
+	var rows atomic.Int32
+
+	// A write holding the key keeps the drop waiting; once it lands the
+	// drop counts again and keeps the resource.
+	writing := make(chan struct{})
+	release := make(chan struct{})
+	writeDone := make(chan error)
+	go func() {
+		writeDone <- g.Write("c_768", func() error {
+			close(writing)
+			<-release
+			rows.Add(1)
+			return nil
+		})
+	}()
+	<-writing
+
+	type dropResult struct {
+		dropped bool
+		err     error
+	}
+	dropDone := make(chan dropResult)
+	var drops atomic.Int32
+	go func() {
+		dropped, err := g.DropIfEmpty("c_768",
+			func() (int, error) { return int(rows.Load()), nil },
+			func() error { drops.Add(1); return nil })
+		dropDone <- dropResult{dropped, err}
+	}()
+
+	select {
+	case <-dropDone:
+		t.Fatal("drop ran while a write held the key")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	require.NoError(t, <-writeDone)
+	res := <-dropDone
+	require.NoError(t, res.err)
+	assert.False(t, res.dropped)
+	assert.Zero(t, drops.Load())
+
+	// Other keys are not held off.
+	require.NoError(t, g.Write("c_1024", func() error { return nil }))
+
+	rows.Store(0)
+	dropped, err := g.DropIfEmpty("c_768",
+		func() (int, error) { return int(rows.Load()), nil },
+		func() error { drops.Add(1); return nil })
+	require.NoError(t, err)
+	assert.True(t, dropped)
+	assert.Equal(t, int32(1), drops.Load())
+
+	errCount := errors.New("count failed")
+	dropped, err = g.DropIfEmpty("c_768",
+		func() (int, error) { return 0, errCount },
+		func() error { drops.Add(1); return nil })
+	assert.ErrorIs(t, err, errCount)
+	assert.False(t, dropped)
+	assert.Equal(t, int32(1), drops.Load(), "a failed count never drops")
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseRangeHeader parses an HTTP "Range: bytes=..." request header into the
+// offset and length arguments of FileService.GetFileRange (length 0 means
+// "to the end"). size is the file size if known, -1 otherwise; it is only
+// needed to resolve suffix ranges ("bytes=-500").
+//
+// ok is false when the whole file should be served instead: no header, a
+// unit other than bytes, several ranges, malformed syntax, or a suffix range
+// of unknown size. Ignoring such headers is allowed by RFC 9110.
+func ParseRangeHeader(header string, size int64) (offset, length int64, ok bool) {
+	spec, found := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false
+	}
+	first, last = strings.TrimSpace(first), strings.TrimSpace(last)
+
+	if first == "" {
+		suffix, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || suffix <= 0 || size < 0 {
+			return 0, 0, false
+		}
+		if suffix >= size {
+			return 0, 0, true
+		}
+		return size - suffix, suffix, true
+	}
+
+	offset, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, 0, false
+	}
+	if last == "" {
+		return offset, 0, true
+	}
+	end, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || end < offset {
+		return 0, 0, false
+	}
+	return offset, end - offset + 1, true
+}
+
+// ContentRange formats the Content-Range response header for a range read
+// of offset and length (0 meaning "to the end") from a file of size bytes,
+// and returns the number of bytes the response carries, -1 if unknown. It
+// returns "" when neither the size nor the length is known.
+func ContentRange(offset, length, size int64) (string, int64) {
+	if size < 0 {
+		if length <= 0 {
+			return "", -1
+		}
+		return fmt.Sprintf("bytes %d-%d/*", offset, offset+length-1), -1
+	}
+	end := size - 1
+	if length > 0 && offset+length-1 < end {
+		end = offset + length - 1
+	}
+	return fmt.Sprintf("bytes %d-%d/%d", offset, end, size), end - offset + 1
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRangeHeader(t *testing.T) {
+	cases := []struct {
+		header         string
+		size           int64
+		offset, length int64
+		ok             bool
+	}{
+		{"bytes=0-99", 1000, 0, 100, true},
+		{"bytes=500-", 1000, 500, 0, true},
+		{"bytes=500-", -1, 500, 0, true},
+		{"bytes=-200", 1000, 800, 200, true},
+		{"bytes=-2000", 1000, 0, 0, true},
+		{"bytes=-200", -1, 0, 0, false},
+		{"bytes=0-1,5-9", 1000, 0, 0, false},
+		{"bytes=9-5", 1000, 0, 0, false},
+		{"items=0-9", 1000, 0, 0, false},
+		{"", 1000, 0, 0, false},
+	}
+	for _, tc := range cases {
+		offset, length, ok := ParseRangeHeader(tc.header, tc.size)
+		assert.Equal(t, tc.ok, ok, tc.header)
+		assert.Equal(t, tc.offset, offset, tc.header)
+		assert.Equal(t, tc.length, length, tc.header)
+	}
+}
+
+func TestContentRange(t *testing.T) {
+	value, n := ContentRange(0, 100, 1000)
+	assert.Equal(t, "bytes 0-99/1000", value)
+	assert.Equal(t, int64(100), n)
+
+	value, n = ContentRange(900, 0, 1000)
+	assert.Equal(t, "bytes 900-999/1000", value)
+	assert.Equal(t, int64(100), n)
+
+	value, n = ContentRange(950, 100, 1000)
+	assert.Equal(t, "bytes 950-999/1000", value)
+	assert.Equal(t, int64(50), n)
+
+	value, _ = ContentRange(10, 0, -1)
+	assert.Equal(t, "", value)
+}
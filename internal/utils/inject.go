@@ -145,6 +145,9 @@ type sqlValidator struct {
 	searchScopeKBIDs        []string
 	searchScopeKnowledgeIDs []string
 	searchScopes            []SearchScope
+
+	// Excluded knowledge filtering (documents hidden by their ACL)
+	excludedKnowledgeIDs []string
 }
 
 // SearchScope describes one allowed knowledge scope for SQL query injection.
@@ -651,6 +654,14 @@ func WithSearchScopes(scopes []SearchScope) SQLValidationOption {
 	}
 }
 
+// WithExcludedKnowledgeFilter hides the given documents: knowledges rows by id
+// and chunks rows by knowledge_id.
+func WithExcludedKnowledgeFilter(knowledgeIDs []string) SQLValidationOption {
+	return func(v *sqlValidator) {
+		v.excludedKnowledgeIDs = append([]string(nil), knowledgeIDs...)
+	}
+}
+
 // WithSecurityDefaults applies a comprehensive set of security validations
 func WithSecurityDefaults(tenantID uint64) SQLValidationOption {
 	return func(v *sqlValidator) {
@@ -857,7 +868,8 @@ func ValidateAndSecureSQL(sql string, opts ...SQLValidationOption) (string, *SQL
 	}
 
 	// If no SQL rewriting is enabled, return original SQL
-	if !validator.enableTenantInjection && !validator.enableSoftDeleteInjection && !validator.enableHiddenKBFilter && !validator.enableSearchScopeFilter {
+	if !validator.enableTenantInjection && !validator.enableSoftDeleteInjection && !validator.enableHiddenKBFilter && !validator.enableSearchScopeFilter &&
+		len(validator.excludedKnowledgeIDs) == 0 {
 		return sql, validationResult, nil
 	}
 
@@ -884,6 +896,8 @@ func ValidateAndSecureSQL(sql string, opts ...SQLValidationOption) (string, *SQL
 	securedSQL = validator.injectHiddenKBFilter(securedSQL, tablesInQuery)
 	// Inject search scope filter (restrict to allowed KBs and knowledges)
 	securedSQL = validator.injectSearchScopeConditions(securedSQL, tablesInQuery)
+	// Inject excluded knowledge filter (hide documents denied by their ACL)
+	securedSQL = validator.injectExcludedKnowledgeConditions(securedSQL, tablesInQuery)
 
 	return securedSQL, validationResult, nil
 }
@@ -1077,6 +1091,27 @@ func (v *sqlValidator) injectSearchScopeConditions(sql string, tablesInQuery map
 	return InjectAndConditions(sql, strings.Join(conditions, " AND "))
 }
 
+// injectExcludedKnowledgeConditions removes the excluded documents and their
+// chunks from the query.
+func (v *sqlValidator) injectExcludedKnowledgeConditions(sql string, tablesInQuery map[string]string) string {
+	if len(v.excludedKnowledgeIDs) == 0 {
+		return sql
+	}
+	excluded := strings.Join(quoteStringSlice(v.excludedKnowledgeIDs), ", ")
+
+	var conditions []string
+	if alias, ok := tablesInQuery["knowledges"]; ok {
+		conditions = append(conditions, fmt.Sprintf("%s.id NOT IN (%s)", alias, excluded))
+	}
+	if alias, ok := tablesInQuery["chunks"]; ok {
+		conditions = append(conditions, fmt.Sprintf("%s.knowledge_id NOT IN (%s)", alias, excluded))
+	}
+	if len(conditions) == 0 {
+		return sql
+	}
+	return InjectAndConditions(sql, strings.Join(conditions, " AND "))
+}
+
 func (v *sqlValidator) injectStructuredSearchScopeConditions(sql string, tablesInQuery map[string]string) string {
 	var conditions []string
 
@@ -481,6 +481,36 @@ func TestValidateAndSecureSQL_WithStructuredSearchScopes(t *testing.T) {
 	}
 }
 
+func TestValidateAndSecureSQL_WithExcludedKnowledgeFilter(t *testing.T) {
+	securedSQL, validation, err := ValidateAndSecureSQL(
+		"SELECT c.content, k.title FROM chunks c JOIN knowledges k ON c.knowledge_id = k.id",
+		WithExcludedKnowledgeFilter([]string{"doc-1", "doc'2"}),
+	)
+	if err != nil {
+		t.Fatalf("ValidateAndSecureSQL() error = %v", err)
+	}
+	if !validation.Valid {
+		t.Fatalf("expected validation to pass, got %#v", validation.Errors)
+	}
+
+	for _, want := range []string{
+		"k.id NOT IN ('doc-1', 'doc''2')",
+		"c.knowledge_id NOT IN ('doc-1', 'doc''2')",
+	} {
+		if !strings.Contains(securedSQL, want) {
+			t.Fatalf("secured SQL missing %q:\n%s", want, securedSQL)
+		}
+	}
+
+	securedSQL, _, err = ValidateAndSecureSQL("SELECT id FROM chunks", WithExcludedKnowledgeFilter(nil))
+	if err != nil {
+		t.Fatalf("ValidateAndSecureSQL() error = %v", err)
+	}
+	if strings.Contains(securedSQL, "NOT IN") {
+		t.Fatalf("no excluded documents must leave the query untouched:\n%s", securedSQL)
+	}
+}
+
 func BenchmarkInjectAndConditions(b *testing.B) {
 	const sql = "SELECT id, title FROM docs WHERE status = 'active' ORDER BY created_at LIMIT 50"
 	for i := 0; i < b.N; i++ {
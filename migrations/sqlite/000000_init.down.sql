@@ -1,3 +1,11 @@
+DROP TABLE IF EXISTS memory_session_summaries;
+DROP TABLE IF EXISTS memory_profile_fact_sources;
+DROP TABLE IF EXISTS memory_profile_facts;
+DROP TABLE IF EXISTS memory_relationships;
+DROP TABLE IF EXISTS memory_mentions;
+DROP TABLE IF EXISTS memory_entity_aliases;
+DROP TABLE IF EXISTS memory_entities;
+DROP TABLE IF EXISTS memory_episodes;
 DROP TABLE IF EXISTS tenant_invitations;
 DROP TABLE IF EXISTS user_kb_pins;
 DROP TABLE IF EXISTS user_resource_favorites;
@@ -10,6 +18,22 @@ DROP TABLE IF EXISTS organizations;
 DROP TABLE IF EXISTS custom_agents;
 DROP TABLE IF EXISTS mcp_tool_approvals;
 DROP TABLE IF EXISTS mcp_services;
+DROP TABLE IF EXISTS knowledge_gaps;
+DROP TABLE IF EXISTS query_log_documents;
+DROP TABLE IF EXISTS query_log_knowledge_bases;
+DROP TABLE IF EXISTS query_logs;
+DROP TABLE IF EXISTS experiment_samples;
+DROP TABLE IF EXISTS experiments;
+DROP TABLE IF EXISTS message_feedback_sources;
+DROP TABLE IF EXISTS message_feedbacks;
+DROP TABLE IF EXISTS eval_run_results;
+DROP TABLE IF EXISTS eval_runs;
+DROP TABLE IF EXISTS eval_dataset_items;
+DROP TABLE IF EXISTS eval_datasets;
+DROP TABLE IF EXISTS retention_policies;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS tag_access_rules;
+DROP TABLE IF EXISTS knowledge_tag_relations;
 DROP TABLE IF EXISTS knowledge_tags;
 DROP TABLE IF EXISTS auth_tokens;
 DROP TABLE IF EXISTS audit_logs;
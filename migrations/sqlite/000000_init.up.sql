@@ -5,6 +5,7 @@ CREATE TABLE IF NOT EXISTS tenants (
     name VARCHAR(255) NOT NULL,
     description TEXT,
     api_key VARCHAR(256) NOT NULL,
+    api_key_role VARCHAR(32) NOT NULL DEFAULT 'admin',
     retriever_engines TEXT NOT NULL DEFAULT '[]',
     status VARCHAR(50) DEFAULT 'active',
     business VARCHAR(255) NOT NULL,
@@ -19,6 +20,10 @@ CREATE TABLE IF NOT EXISTS tenants (
     credentials TEXT DEFAULT NULL,
     chat_history_config TEXT,
     retrieval_config TEXT,
+    memory_extraction_config TEXT,
+    guardrails_config TEXT,
+    pipeline_config TEXT,
+    token_budget TEXT,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     deleted_at DATETIME
@@ -66,11 +71,14 @@ CREATE TABLE IF NOT EXISTS knowledge_bases (
     extract_config TEXT NULL DEFAULT NULL,
     faq_config TEXT,
     question_generation_config TEXT NULL,
+    auto_tag_config TEXT,
     is_temporary BOOLEAN NOT NULL DEFAULT 0,
     is_pinned INTEGER NOT NULL DEFAULT 0,
     pinned_at DATETIME NULL,
     asr_config TEXT,
     vector_store_id VARCHAR(36),
+    index_generation INTEGER NOT NULL DEFAULT 0,
+    pending_index_generation INTEGER NOT NULL DEFAULT 0,
     creator_id VARCHAR(36),
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
@@ -100,6 +108,8 @@ CREATE TABLE IF NOT EXISTS knowledges (
     file_path TEXT,
     file_hash VARCHAR(64),
     storage_size BIGINT NOT NULL DEFAULT 0,
+    encryption_key_id VARCHAR(512) NOT NULL DEFAULT '',
+    preview_path TEXT NOT NULL DEFAULT '',
     metadata TEXT,
     tag_id VARCHAR(36),
     summary_status VARCHAR(32) DEFAULT 'none',
@@ -143,6 +153,9 @@ CREATE TABLE IF NOT EXISTS sessions (
     user_id VARCHAR(36),
     is_pinned BOOLEAN NOT NULL DEFAULT 0,
     pinned_at DATETIME,
+    memory_policy TEXT,
+    rerank_config TEXT,
+    pipeline_config TEXT,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     deleted_at DATETIME
@@ -162,6 +175,9 @@ CREATE TABLE IF NOT EXISTS messages (
     content TEXT NOT NULL,
     rendered_content TEXT NOT NULL DEFAULT '',
     knowledge_references TEXT NOT NULL DEFAULT '[]',
+    citations TEXT DEFAULT '[]',
+    verification TEXT DEFAULT NULL,
+    metadata TEXT DEFAULT NULL,
     agent_steps TEXT DEFAULT NULL,
     mentioned_items TEXT DEFAULT '[]',
     images TEXT DEFAULT '[]',
@@ -202,6 +218,7 @@ CREATE TABLE IF NOT EXISTS chunks (
     content_hash VARCHAR(64),
     flags INTEGER NOT NULL DEFAULT 1,
     seq_id INTEGER,
+    index_generation INTEGER NOT NULL DEFAULT 0,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     deleted_at DATETIME
@@ -213,6 +230,7 @@ CREATE INDEX IF NOT EXISTS idx_chunks_chunk_type ON chunks(chunk_type);
 CREATE INDEX IF NOT EXISTS idx_chunks_tag ON chunks(tag_id);
 CREATE INDEX IF NOT EXISTS idx_chunks_content_hash ON chunks(content_hash);
 CREATE UNIQUE INDEX IF NOT EXISTS idx_chunks_seq_id ON chunks(seq_id);
+CREATE INDEX IF NOT EXISTS idx_chunks_kb_index_generation ON chunks(knowledge_base_id, index_generation);
 CREATE INDEX IF NOT EXISTS idx_chunks_kb_tenant ON chunks(knowledge_base_id, tenant_id);
 CREATE INDEX IF NOT EXISTS idx_chunks_knowledge_enabled ON chunks(knowledge_id, is_enabled, deleted_at);
 
@@ -229,6 +247,8 @@ CREATE TABLE IF NOT EXISTS users (
     -- SQLite has no JSONB; store as TEXT and let GORM (de)serialise via
     -- the driver.Valuer / sql.Scanner methods on types.UserPreferences.
     preferences TEXT NOT NULL DEFAULT '{}',
+    -- External groups from the SSO group claim, JSON array as TEXT.
+    sso_groups TEXT,
     created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
     deleted_at DATETIME
@@ -366,6 +386,7 @@ CREATE TABLE IF NOT EXISTS knowledge_tags (
     id VARCHAR(36) PRIMARY KEY,
     tenant_id INTEGER NOT NULL,
     knowledge_base_id VARCHAR(36) NOT NULL,
+    parent_id VARCHAR(36) NOT NULL DEFAULT '',
     name VARCHAR(128) NOT NULL,
     color VARCHAR(32),
     sort_order INTEGER NOT NULL DEFAULT 0,
@@ -377,8 +398,19 @@ CREATE TABLE IF NOT EXISTS knowledge_tags (
 
 CREATE UNIQUE INDEX IF NOT EXISTS idx_knowledge_tags_kb_name ON knowledge_tags(tenant_id, knowledge_base_id, name);
 CREATE INDEX IF NOT EXISTS idx_knowledge_tags_kb ON knowledge_tags(tenant_id, knowledge_base_id);
+CREATE INDEX IF NOT EXISTS idx_knowledge_tags_parent ON knowledge_tags(parent_id);
 CREATE UNIQUE INDEX IF NOT EXISTS idx_knowledge_tags_seq_id ON knowledge_tags(seq_id);
 
+-- Document tags; sqlite mirror of migrations 000063 and 000084
+CREATE TABLE IF NOT EXISTS knowledge_tag_relations (
+    knowledge_id VARCHAR(36) NOT NULL,
+    tag_id VARCHAR(36) NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    source VARCHAR(16) NOT NULL DEFAULT 'manual',
+    PRIMARY KEY (knowledge_id, tag_id)
+);
+CREATE INDEX IF NOT EXISTS idx_ktr_tag ON knowledge_tag_relations(tag_id);
+
 CREATE TABLE IF NOT EXISTS mcp_services (
     id VARCHAR(36) PRIMARY KEY,
     tenant_id INTEGER NOT NULL,
@@ -751,3 +783,479 @@ CREATE UNIQUE INDEX IF NOT EXISTS idx_vector_stores_name_tenant
 CREATE INDEX IF NOT EXISTS idx_vector_stores_tenant_id ON vector_stores(tenant_id);
 CREATE INDEX IF NOT EXISTS idx_vector_stores_engine_type ON vector_stores(engine_type);
 CREATE INDEX IF NOT EXISTS idx_vector_stores_deleted_at ON vector_stores(deleted_at);
+
+-- memory graph — sqlite mirror of migrations 000067, 000068 and 000070, used
+-- when MEMORY_DRIVER=database. Embeddings are JSON arrays in TEXT columns.
+CREATE TABLE IF NOT EXISTS memory_episodes (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    user_id VARCHAR(36) NOT NULL,
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    summary TEXT NOT NULL DEFAULT '',
+    salience REAL NOT NULL DEFAULT 0,
+    access_count INTEGER NOT NULL DEFAULT 0,
+    last_accessed_at DATETIME NULL,
+    archived_at DATETIME NULL,
+    embedding_model VARCHAR(64) NOT NULL DEFAULT '',
+    embedding TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_memory_episodes_user_created_at
+    ON memory_episodes(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_memory_episodes_tenant_id
+    ON memory_episodes(tenant_id);
+
+CREATE TABLE IF NOT EXISTS memory_entities (
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    name VARCHAR(512) NOT NULL,
+    type VARCHAR(128) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    embedding_model VARCHAR(64) NOT NULL DEFAULT '',
+    embedding TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (tenant_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS memory_entity_aliases (
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    alias VARCHAR(512) NOT NULL,
+    entity_name VARCHAR(512) NOT NULL,
+    PRIMARY KEY (tenant_id, alias)
+);
+CREATE INDEX IF NOT EXISTS idx_memory_entity_aliases_tenant_entity
+    ON memory_entity_aliases(tenant_id, entity_name);
+
+CREATE TABLE IF NOT EXISTS memory_mentions (
+    episode_id VARCHAR(36) NOT NULL,
+    entity_name VARCHAR(512) NOT NULL,
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (episode_id, entity_name)
+);
+CREATE INDEX IF NOT EXISTS idx_memory_mentions_tenant_entity
+    ON memory_mentions(tenant_id, entity_name);
+
+CREATE TABLE IF NOT EXISTS memory_relationships (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    source VARCHAR(512) NOT NULL,
+    target VARCHAR(512) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    weight REAL NOT NULL DEFAULT 0,
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    episode_id VARCHAR(36) NOT NULL DEFAULT '',
+    valid_from DATETIME NULL,
+    valid_to DATETIME NULL,
+    invalidated_by VARCHAR(36) NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_memory_relationships_tenant_source
+    ON memory_relationships(tenant_id, source);
+CREATE INDEX IF NOT EXISTS idx_memory_relationships_tenant_target
+    ON memory_relationships(tenant_id, target);
+
+CREATE TABLE IF NOT EXISTS memory_profile_facts (
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    user_id VARCHAR(36) NOT NULL,
+    category VARCHAR(32) NOT NULL,
+    fact_key VARCHAR(128) NOT NULL,
+    value TEXT NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, user_id, category, fact_key)
+);
+
+CREATE TABLE IF NOT EXISTS memory_profile_fact_sources (
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    user_id VARCHAR(36) NOT NULL,
+    category VARCHAR(32) NOT NULL,
+    fact_key VARCHAR(128) NOT NULL,
+    episode_id VARCHAR(36) NOT NULL,
+    PRIMARY KEY (tenant_id, user_id, category, fact_key, episode_id)
+);
+CREATE INDEX IF NOT EXISTS idx_memory_profile_fact_sources_episode_id
+    ON memory_profile_fact_sources(episode_id);
+
+CREATE TABLE IF NOT EXISTS memory_session_summaries (
+    session_id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL DEFAULT 0,
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    summary TEXT NOT NULL DEFAULT '',
+    summarized_until DATETIME,
+    message_count INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- knowledge base graph — sqlite mirror of migration 000072, used when
+-- GRAPH_DRIVER=database. Chunk IDs and attributes are JSON arrays.
+CREATE TABLE IF NOT EXISTS kb_graph_nodes (
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL DEFAULT '',
+    name VARCHAR(512) NOT NULL,
+    attributes TEXT NOT NULL DEFAULT '[]',
+    chunks TEXT NOT NULL DEFAULT '[]',
+    PRIMARY KEY (knowledge_base_id, knowledge_id, name)
+);
+CREATE INDEX IF NOT EXISTS idx_kb_graph_nodes_kb_name
+    ON kb_graph_nodes(knowledge_base_id, name);
+
+CREATE TABLE IF NOT EXISTS kb_graph_relations (
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL DEFAULT '',
+    source VARCHAR(512) NOT NULL,
+    target VARCHAR(512) NOT NULL,
+    type VARCHAR(255) NOT NULL DEFAULT '',
+    PRIMARY KEY (knowledge_base_id, knowledge_id, source, target, type)
+);
+CREATE INDEX IF NOT EXISTS idx_kb_graph_relations_kb_source
+    ON kb_graph_relations(knowledge_base_id, source);
+CREATE INDEX IF NOT EXISTS idx_kb_graph_relations_kb_target
+    ON kb_graph_relations(knowledge_base_id, target);
+
+-- sql connections — sqlite mirror of migration 000076
+CREATE TABLE IF NOT EXISTS sql_connections (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    driver VARCHAR(50) NOT NULL,
+    parameters TEXT,
+    allowed_tables TEXT,
+    max_rows INTEGER NOT NULL DEFAULT 0,
+    query_timeout INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME NULL
+);
+CREATE INDEX IF NOT EXISTS idx_sql_connections_tenant_id ON sql_connections (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_sql_connections_deleted_at ON sql_connections (deleted_at);
+
+-- Token usage per tenant, day, model and session
+CREATE TABLE IF NOT EXISTS token_usage_daily (
+    tenant_id INTEGER NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    model_id VARCHAR(64) NOT NULL DEFAULT '',
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    model_name VARCHAR(255) NOT NULL DEFAULT '',
+    requests INTEGER NOT NULL DEFAULT 0,
+    estimated_requests INTEGER NOT NULL DEFAULT 0,
+    prompt_tokens INTEGER NOT NULL DEFAULT 0,
+    completion_tokens INTEGER NOT NULL DEFAULT 0,
+    embedding_tokens INTEGER NOT NULL DEFAULT 0,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, day, model_id, session_id)
+);
+
+-- File versions of knowledge updated in place
+CREATE TABLE IF NOT EXISTS knowledge_versions (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    version INTEGER NOT NULL,
+    file_name VARCHAR(255) NOT NULL DEFAULT '',
+    file_type VARCHAR(50) NOT NULL DEFAULT '',
+    file_size INTEGER NOT NULL DEFAULT 0,
+    file_hash VARCHAR(64) NOT NULL DEFAULT '',
+    chunks_added INTEGER NOT NULL DEFAULT 0,
+    chunks_reused INTEGER NOT NULL DEFAULT 0,
+    chunks_removed INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_knowledge_versions_knowledge_version ON knowledge_versions (tenant_id, knowledge_id, version);
+
+-- Knowledge base snapshots and their schedules
+CREATE TABLE IF NOT EXISTS kb_snapshots (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    knowledge_base_name VARCHAR(255) NOT NULL DEFAULT '',
+    trigger VARCHAR(16) NOT NULL DEFAULT 'manual',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    parts TEXT,
+    size INTEGER NOT NULL DEFAULT 0,
+    knowledge_count INTEGER NOT NULL DEFAULT 0,
+    chunk_count INTEGER NOT NULL DEFAULT 0,
+    vector_count INTEGER NOT NULL DEFAULT 0,
+    vectors_included BOOLEAN NOT NULL DEFAULT 0,
+    embedding_model_id VARCHAR(64) NOT NULL DEFAULT '',
+    error_message TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    completed_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_kb_snapshots_tenant_kb ON kb_snapshots (tenant_id, knowledge_base_id, created_at);
+
+CREATE TABLE IF NOT EXISTS kb_snapshot_policies (
+    knowledge_base_id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT 0,
+    interval_hours INTEGER NOT NULL DEFAULT 24,
+    retain INTEGER NOT NULL DEFAULT 7,
+    next_run_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_kb_snapshot_policies_due ON kb_snapshot_policies (enabled, next_run_at);
+
+CREATE TABLE IF NOT EXISTS kb_duplicate_reports (
+    knowledge_base_id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'running',
+    threshold REAL NOT NULL DEFAULT 0.9,
+    chunk_count INTEGER NOT NULL DEFAULT 0,
+    document_count INTEGER NOT NULL DEFAULT 0,
+    truncated BOOLEAN NOT NULL DEFAULT 0,
+    document_groups TEXT,
+    chunk_groups TEXT,
+    redundant_document_count INTEGER NOT NULL DEFAULT 0,
+    redundant_chunk_count INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    completed_at DATETIME,
+    removed_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_kb_duplicate_reports_tenant ON kb_duplicate_reports (tenant_id);
+
+CREATE TABLE IF NOT EXISTS tag_access_rules (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    subject_type VARCHAR(16) NOT NULL,
+    subject_id VARCHAR(64) NOT NULL DEFAULT '',
+    tag_ids TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tag_access_rules_subject ON tag_access_rules (tenant_id, knowledge_base_id, subject_type, subject_id);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    key_prefix VARCHAR(16) NOT NULL DEFAULT '',
+    key_hash VARCHAR(64) NOT NULL,
+    role VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    scopes TEXT,
+    knowledge_base_ids TEXT,
+    expires_at DATETIME,
+    last_used_at DATETIME,
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    deleted_at DATETIME
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_id ON api_keys (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_deleted_at ON api_keys (deleted_at);
+
+CREATE TABLE IF NOT EXISTS retention_policies (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT 0,
+    session_retention_days INTEGER NOT NULL DEFAULT 0,
+    memory_retention_days INTEGER NOT NULL DEFAULT 0,
+    knowledge_retention_days INTEGER NOT NULL DEFAULT 0,
+    knowledge_expires_at DATETIME,
+    next_run_at DATETIME,
+    last_run_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_retention_policies_scope ON retention_policies (tenant_id, knowledge_base_id);
+CREATE INDEX IF NOT EXISTS idx_retention_policies_next_run_at ON retention_policies (next_run_at);
+
+CREATE TABLE IF NOT EXISTS eval_datasets (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    item_count INTEGER NOT NULL DEFAULT 0,
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_eval_datasets_tenant_id ON eval_datasets (tenant_id);
+
+CREATE TABLE IF NOT EXISTS eval_dataset_items (
+    id VARCHAR(36) PRIMARY KEY,
+    dataset_id VARCHAR(36) NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    question TEXT NOT NULL,
+    expected_answer TEXT NOT NULL DEFAULT '',
+    expected_sources TEXT NOT NULL DEFAULT '[]'
+);
+CREATE INDEX IF NOT EXISTS idx_eval_dataset_items_dataset_id ON eval_dataset_items (dataset_id, position);
+
+CREATE TABLE IF NOT EXISTS eval_runs (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    dataset_id VARCHAR(36) NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    config TEXT NOT NULL DEFAULT '{}',
+    metrics TEXT,
+    total INTEGER NOT NULL DEFAULT 0,
+    finished INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    err_msg TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    started_at DATETIME,
+    finished_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_eval_runs_tenant_id ON eval_runs (tenant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_eval_runs_dataset_id ON eval_runs (dataset_id);
+
+CREATE TABLE IF NOT EXISTS eval_run_results (
+    id VARCHAR(36) PRIMARY KEY,
+    run_id VARCHAR(36) NOT NULL,
+    item_id VARCHAR(36) NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    question TEXT NOT NULL DEFAULT '',
+    expected_sources INTEGER NOT NULL DEFAULT 0,
+    retrieved TEXT NOT NULL DEFAULT '[]',
+    metrics TEXT NOT NULL DEFAULT '{}',
+    answer TEXT NOT NULL DEFAULT '',
+    judge_reason TEXT NOT NULL DEFAULT '',
+    err_msg TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_eval_run_results_run_id ON eval_run_results (run_id, position);
+
+CREATE TABLE IF NOT EXISTS message_feedbacks (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    session_id VARCHAR(36) NOT NULL,
+    message_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    rating VARCHAR(16) NOT NULL DEFAULT '',
+    comment TEXT NOT NULL DEFAULT '',
+    wrong_sources INTEGER NOT NULL DEFAULT 0,
+    day VARCHAR(10) NOT NULL,
+    question TEXT NOT NULL DEFAULT '',
+    answer TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_message_feedback_user ON message_feedbacks (message_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_message_feedbacks_tenant_id ON message_feedbacks (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_message_feedbacks_day ON message_feedbacks (day);
+
+CREATE TABLE IF NOT EXISTS message_feedback_sources (
+    feedback_id VARCHAR(36) NOT NULL,
+    position INTEGER NOT NULL,
+    tenant_id INTEGER NOT NULL,
+    chunk_id VARCHAR(64) NOT NULL DEFAULT '',
+    knowledge_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_title TEXT NOT NULL DEFAULT '',
+    content TEXT NOT NULL DEFAULT '',
+    score REAL NOT NULL DEFAULT 0,
+    score_bucket INTEGER NOT NULL DEFAULT 0,
+    cited BOOLEAN NOT NULL DEFAULT 0,
+    wrong BOOLEAN NOT NULL DEFAULT 0,
+    PRIMARY KEY (feedback_id, position)
+);
+CREATE INDEX IF NOT EXISTS idx_message_feedback_sources_tenant_id ON message_feedback_sources (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_message_feedback_sources_chunk_id ON message_feedback_sources (chunk_id);
+CREATE INDEX IF NOT EXISTS idx_message_feedback_sources_knowledge_base_id ON message_feedback_sources (knowledge_base_id);
+
+CREATE TABLE IF NOT EXISTS experiments (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    knowledge_base_ids TEXT NOT NULL DEFAULT '[]',
+    sample_percent REAL NOT NULL DEFAULT 0,
+    max_samples INTEGER NOT NULL DEFAULT 0,
+    sampled INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(32) NOT NULL DEFAULT 'running',
+    config TEXT NOT NULL DEFAULT '{}',
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_experiments_tenant_id ON experiments (tenant_id, created_at);
+
+CREATE TABLE IF NOT EXISTS experiment_samples (
+    id VARCHAR(36) PRIMARY KEY,
+    experiment_id VARCHAR(36) NOT NULL,
+    tenant_id INTEGER NOT NULL,
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    message_id VARCHAR(36) NOT NULL DEFAULT '',
+    question TEXT NOT NULL DEFAULT '',
+    production_answer TEXT NOT NULL DEFAULT '',
+    shadow_answer TEXT NOT NULL DEFAULT '',
+    production_sources TEXT NOT NULL DEFAULT '[]',
+    shadow_sources TEXT NOT NULL DEFAULT '[]',
+    source_overlap REAL NOT NULL DEFAULT 0,
+    shadow_latency_ms INTEGER NOT NULL DEFAULT 0,
+    winner VARCHAR(16) NOT NULL DEFAULT '',
+    judge_reason TEXT NOT NULL DEFAULT '',
+    err_msg TEXT NOT NULL DEFAULT '',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_experiment_samples_experiment_id ON experiment_samples (experiment_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_experiment_samples_tenant_id ON experiment_samples (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_experiment_samples_session_id ON experiment_samples (session_id);
+CREATE INDEX IF NOT EXISTS idx_experiment_samples_message_id ON experiment_samples (message_id);
+
+CREATE TABLE IF NOT EXISTS query_logs (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    message_id VARCHAR(36) NOT NULL DEFAULT '',
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    channel VARCHAR(50) NOT NULL DEFAULT '',
+    mode VARCHAR(16) NOT NULL DEFAULT '',
+    query TEXT NOT NULL DEFAULT '',
+    query_hash VARCHAR(64) NOT NULL DEFAULT '',
+    day VARCHAR(10) NOT NULL,
+    searched BOOLEAN NOT NULL DEFAULT 0,
+    retrieved INTEGER NOT NULL DEFAULT 0,
+    zero_result BOOLEAN NOT NULL DEFAULT 0,
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_query_logs_tenant_day ON query_logs (tenant_id, day);
+CREATE INDEX IF NOT EXISTS idx_query_logs_query_hash ON query_logs (query_hash);
+CREATE INDEX IF NOT EXISTS idx_query_logs_session_id ON query_logs (session_id);
+CREATE INDEX IF NOT EXISTS idx_query_logs_message_id ON query_logs (message_id);
+
+CREATE TABLE IF NOT EXISTS query_log_knowledge_bases (
+    query_log_id VARCHAR(36) NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    tenant_id INTEGER NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    zero_result BOOLEAN NOT NULL DEFAULT 0,
+    PRIMARY KEY (query_log_id, knowledge_base_id)
+);
+CREATE INDEX IF NOT EXISTS idx_query_log_knowledge_bases_tenant_day ON query_log_knowledge_bases (tenant_id, day);
+CREATE INDEX IF NOT EXISTS idx_query_log_knowledge_bases_knowledge_base_id ON query_log_knowledge_bases (knowledge_base_id);
+
+CREATE TABLE IF NOT EXISTS query_log_documents (
+    query_log_id VARCHAR(36) NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_title TEXT NOT NULL DEFAULT '',
+    day VARCHAR(10) NOT NULL,
+    chunks INTEGER NOT NULL DEFAULT 0,
+    cited BOOLEAN NOT NULL DEFAULT 0,
+    PRIMARY KEY (query_log_id, knowledge_id)
+);
+CREATE INDEX IF NOT EXISTS idx_query_log_documents_tenant_day ON query_log_documents (tenant_id, day);
+CREATE INDEX IF NOT EXISTS idx_query_log_documents_knowledge_id ON query_log_documents (knowledge_id);
+
+CREATE TABLE IF NOT EXISTS knowledge_gaps (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id INTEGER NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    query_hash VARCHAR(64) NOT NULL,
+    query TEXT NOT NULL DEFAULT '',
+    count INTEGER NOT NULL DEFAULT 0,
+    first_seen_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    embedding TEXT,
+    embedding_model_id VARCHAR(64) NOT NULL DEFAULT ''
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_knowledge_gaps_query ON knowledge_gaps (tenant_id, knowledge_base_id, query_hash);
+CREATE INDEX IF NOT EXISTS idx_knowledge_gaps_last_seen_at ON knowledge_gaps (last_seen_at);
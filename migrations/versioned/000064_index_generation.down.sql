@@ -0,0 +1,16 @@
+-- Migration: 000064_index_generation (down)
+-- Description: Remove index generation columns. Chunks staged by an unfinished
+-- migration would become visible, so they are dropped first.
+DO $$ BEGIN RAISE NOTICE '[Migration 000064 down] Removing index generation columns'; END $$;
+
+DELETE FROM chunks c
+USING knowledge_bases kb
+WHERE c.knowledge_base_id = kb.id
+  AND c.index_generation > kb.index_generation;
+
+DROP INDEX IF EXISTS idx_chunks_kb_index_generation;
+ALTER TABLE chunks DROP COLUMN IF EXISTS index_generation;
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS pending_index_generation;
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS index_generation;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000064 down] index generation columns removed successfully'; END $$;
@@ -0,0 +1,17 @@
+-- Migration: 000064_index_generation
+-- Description: Track index generations so re-chunking / re-embedding migrations
+-- can build a new generation of chunks next to the live one and switch over atomically.
+DO $$ BEGIN RAISE NOTICE '[Migration 000064] Adding index generation columns'; END $$;
+
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS index_generation BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE knowledge_bases ADD COLUMN IF NOT EXISTS pending_index_generation BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE chunks ADD COLUMN IF NOT EXISTS index_generation BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_chunks_kb_index_generation
+    ON chunks(knowledge_base_id, index_generation);
+
+COMMENT ON COLUMN knowledge_bases.index_generation IS 'Chunk generation visible to retrieval';
+COMMENT ON COLUMN knowledge_bases.pending_index_generation IS 'Generation being built by the running index migration, 0 when idle';
+COMMENT ON COLUMN chunks.index_generation IS 'Index generation the chunk was written for; hidden while greater than knowledge_bases.index_generation';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000064] index generation columns added successfully'; END $$;
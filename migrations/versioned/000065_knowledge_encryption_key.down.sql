@@ -0,0 +1,7 @@
+-- Migration: 000065_knowledge_encryption_key (down)
+-- Description: Remove knowledges.encryption_key_id. Stored objects stay encrypted.
+DO $$ BEGIN RAISE NOTICE '[Migration 000065 down] Removing knowledges.encryption_key_id'; END $$;
+
+ALTER TABLE knowledges DROP COLUMN IF EXISTS encryption_key_id;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000065 down] knowledges.encryption_key_id removed successfully'; END $$;
@@ -0,0 +1,10 @@
+-- Migration: 000065_knowledge_encryption_key
+-- Description: Record which server-side encryption key each stored knowledge file
+-- was written with, so files can be re-encrypted after a key rotation.
+DO $$ BEGIN RAISE NOTICE '[Migration 000065] Adding knowledges.encryption_key_id'; END $$;
+
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS encryption_key_id VARCHAR(512) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN knowledges.encryption_key_id IS 'Server-side encryption key of the stored file (KMS key ID, or sse-s3), empty when unencrypted';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000065] knowledges.encryption_key_id added successfully'; END $$;
@@ -0,0 +1,7 @@
+-- Migration: 000066_knowledge_preview_path (down)
+-- Description: Remove knowledges.preview_path. Stored previews are left in place.
+DO $$ BEGIN RAISE NOTICE '[Migration 000066 down] Removing knowledges.preview_path'; END $$;
+
+ALTER TABLE knowledges DROP COLUMN IF EXISTS preview_path;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000066 down] knowledges.preview_path removed successfully'; END $$;
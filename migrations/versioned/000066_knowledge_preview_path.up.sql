@@ -0,0 +1,10 @@
+-- Migration: 000066_knowledge_preview_path
+-- Description: Record the thumbnail generated for image and PDF knowledge files,
+-- stored next to the file as {key}.thumb.jpg.
+DO $$ BEGIN RAISE NOTICE '[Migration 000066] Adding knowledges.preview_path'; END $$;
+
+ALTER TABLE knowledges ADD COLUMN IF NOT EXISTS preview_path TEXT NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN knowledges.preview_path IS 'Storage path of the generated thumbnail / first-page preview, empty when none exists';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000066] knowledges.preview_path added successfully'; END $$;
@@ -0,0 +1,13 @@
+-- Migration: 000067_memory_graph (down)
+-- Description: Drop the memory graph tables used by MEMORY_DRIVER=database.
+DO $$ BEGIN RAISE NOTICE '[Migration 000067 down] Dropping memory graph tables'; END $$;
+
+DROP TABLE IF EXISTS memory_profile_fact_sources;
+DROP TABLE IF EXISTS memory_profile_facts;
+DROP TABLE IF EXISTS memory_relationships;
+DROP TABLE IF EXISTS memory_mentions;
+DROP TABLE IF EXISTS memory_entity_aliases;
+DROP TABLE IF EXISTS memory_entities;
+DROP TABLE IF EXISTS memory_episodes;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000067 down] Memory graph tables dropped'; END $$;
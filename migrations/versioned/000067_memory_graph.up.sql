@@ -0,0 +1,88 @@
+-- Migration: 000067_memory_graph
+-- Description: Store the conversation memory graph in the application database
+-- for deployments without Neo4j (MEMORY_DRIVER=database). Episodes and
+-- entities are the nodes; mentions, relationships and profile fact sources are
+-- adjacency tables traversed with recursive CTEs. Embeddings are JSON arrays
+-- scored in the application, so the tables do not depend on pgvector.
+DO $$ BEGIN RAISE NOTICE '[Migration 000067] Creating memory graph tables'; END $$;
+
+CREATE TABLE IF NOT EXISTS memory_episodes (
+    id               VARCHAR(36)  PRIMARY KEY,
+    tenant_id        BIGINT       NOT NULL DEFAULT 0,
+    user_id          VARCHAR(36)  NOT NULL,
+    session_id       VARCHAR(36)  NOT NULL DEFAULT '',
+    summary          TEXT         NOT NULL DEFAULT '',
+    salience         DOUBLE PRECISION NOT NULL DEFAULT 0,
+    access_count     INTEGER      NOT NULL DEFAULT 0,
+    last_accessed_at TIMESTAMP WITH TIME ZONE,
+    archived_at      TIMESTAMP WITH TIME ZONE,
+    embedding_model  VARCHAR(64)  NOT NULL DEFAULT '',
+    embedding        TEXT         NOT NULL DEFAULT '',
+    created_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_memory_episodes_user_created_at
+    ON memory_episodes (user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_memory_episodes_tenant_id
+    ON memory_episodes (tenant_id);
+
+-- Entities are shared by every user and merged by name, as in Neo4j.
+CREATE TABLE IF NOT EXISTS memory_entities (
+    name            VARCHAR(512) PRIMARY KEY,
+    type            VARCHAR(128) NOT NULL DEFAULT '',
+    description     TEXT         NOT NULL DEFAULT '',
+    embedding_model VARCHAR(64)  NOT NULL DEFAULT '',
+    embedding       TEXT         NOT NULL DEFAULT ''
+);
+
+-- Names of duplicates merged into an entity by consolidation.
+CREATE TABLE IF NOT EXISTS memory_entity_aliases (
+    alias       VARCHAR(512) PRIMARY KEY,
+    entity_name VARCHAR(512) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_memory_entity_aliases_entity_name
+    ON memory_entity_aliases (entity_name);
+
+CREATE TABLE IF NOT EXISTS memory_mentions (
+    episode_id  VARCHAR(36)  NOT NULL,
+    entity_name VARCHAR(512) NOT NULL,
+    PRIMARY KEY (episode_id, entity_name)
+);
+CREATE INDEX IF NOT EXISTS idx_memory_mentions_entity_name
+    ON memory_mentions (entity_name);
+
+-- Relationships are never overwritten: contradicted ones get valid_to set.
+CREATE TABLE IF NOT EXISTS memory_relationships (
+    id             VARCHAR(36)  PRIMARY KEY,
+    source         VARCHAR(512) NOT NULL,
+    target         VARCHAR(512) NOT NULL,
+    description    TEXT         NOT NULL DEFAULT '',
+    weight         DOUBLE PRECISION NOT NULL DEFAULT 0,
+    user_id        VARCHAR(36)  NOT NULL DEFAULT '',
+    episode_id     VARCHAR(36)  NOT NULL DEFAULT '',
+    valid_from     TIMESTAMP WITH TIME ZONE,
+    valid_to       TIMESTAMP WITH TIME ZONE,
+    invalidated_by VARCHAR(36)  NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_memory_relationships_source ON memory_relationships (source);
+CREATE INDEX IF NOT EXISTS idx_memory_relationships_target ON memory_relationships (target);
+
+CREATE TABLE IF NOT EXISTS memory_profile_facts (
+    user_id    VARCHAR(36)  NOT NULL,
+    category   VARCHAR(32)  NOT NULL,
+    fact_key   VARCHAR(128) NOT NULL,
+    value      TEXT         NOT NULL DEFAULT '',
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, category, fact_key)
+);
+
+CREATE TABLE IF NOT EXISTS memory_profile_fact_sources (
+    user_id    VARCHAR(36)  NOT NULL,
+    category   VARCHAR(32)  NOT NULL,
+    fact_key   VARCHAR(128) NOT NULL,
+    episode_id VARCHAR(36)  NOT NULL,
+    PRIMARY KEY (user_id, category, fact_key, episode_id)
+);
+CREATE INDEX IF NOT EXISTS idx_memory_profile_fact_sources_episode_id
+    ON memory_profile_fact_sources (episode_id);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000067] Memory graph tables ready'; END $$;
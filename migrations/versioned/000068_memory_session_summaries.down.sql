@@ -0,0 +1,7 @@
+-- Migration: 000068_memory_session_summaries (down)
+-- Description: Drop the working memory session summaries.
+DO $$ BEGIN RAISE NOTICE '[Migration 000068 down] Dropping memory_session_summaries table'; END $$;
+
+DROP TABLE IF EXISTS memory_session_summaries;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000068 down] memory_session_summaries table dropped'; END $$;
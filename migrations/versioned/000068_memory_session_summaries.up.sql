@@ -0,0 +1,15 @@
+-- Migration: 000068_memory_session_summaries
+-- Description: Store the rolling per-session summaries of working memory for
+-- MEMORY_DRIVER=database.
+DO $$ BEGIN RAISE NOTICE '[Migration 000068] Creating memory_session_summaries table'; END $$;
+
+CREATE TABLE IF NOT EXISTS memory_session_summaries (
+    session_id       VARCHAR(36) PRIMARY KEY,
+    user_id          VARCHAR(36) NOT NULL DEFAULT '',
+    summary          TEXT        NOT NULL DEFAULT '',
+    summarized_until TIMESTAMP WITH TIME ZONE,
+    message_count    INTEGER     NOT NULL DEFAULT 0,
+    updated_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000068] memory_session_summaries table ready'; END $$;
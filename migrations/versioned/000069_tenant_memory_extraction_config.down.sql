@@ -0,0 +1,7 @@
+-- Migration: 000069_tenant_memory_extraction_config (down)
+-- Description: Remove the per-tenant memory extraction config.
+DO $$ BEGIN RAISE NOTICE '[Migration 000069 down] Dropping memory_extraction_config from tenants'; END $$;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS memory_extraction_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000069 down] memory_extraction_config dropped'; END $$;
@@ -0,0 +1,8 @@
+-- Migration: 000069_tenant_memory_extraction_config
+-- Description: Let tenants override the conversation memory extraction
+-- prompt, entity type taxonomy and output schema.
+DO $$ BEGIN RAISE NOTICE '[Migration 000069] Adding memory_extraction_config to tenants'; END $$;
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS memory_extraction_config JSONB;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000069] memory_extraction_config added'; END $$;
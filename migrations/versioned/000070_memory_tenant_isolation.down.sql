@@ -0,0 +1,53 @@
+-- Migration: 000070_memory_tenant_isolation (down)
+-- Description: Share memory graph entities across tenants again. Where several
+-- tenants have an entity, alias or profile fact of the same name, the one of
+-- the lowest tenant ID is kept.
+DO $$ BEGIN RAISE NOTICE '[Migration 000070 down] Merging memory graph tenants'; END $$;
+
+ALTER TABLE memory_session_summaries DROP COLUMN IF EXISTS tenant_id;
+
+DELETE FROM memory_profile_fact_sources a
+USING memory_profile_fact_sources b
+WHERE a.user_id = b.user_id AND a.category = b.category AND a.fact_key = b.fact_key
+    AND a.episode_id = b.episode_id AND a.tenant_id > b.tenant_id;
+ALTER TABLE memory_profile_fact_sources DROP CONSTRAINT IF EXISTS memory_profile_fact_sources_pkey;
+ALTER TABLE memory_profile_fact_sources DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE memory_profile_fact_sources ADD PRIMARY KEY (user_id, category, fact_key, episode_id);
+
+DELETE FROM memory_profile_facts a
+USING memory_profile_facts b
+WHERE a.user_id = b.user_id AND a.category = b.category AND a.fact_key = b.fact_key
+    AND a.tenant_id > b.tenant_id;
+ALTER TABLE memory_profile_facts DROP CONSTRAINT IF EXISTS memory_profile_facts_pkey;
+ALTER TABLE memory_profile_facts DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE memory_profile_facts ADD PRIMARY KEY (user_id, category, fact_key);
+
+DROP INDEX IF EXISTS idx_memory_relationships_tenant_source;
+DROP INDEX IF EXISTS idx_memory_relationships_tenant_target;
+ALTER TABLE memory_relationships DROP COLUMN IF EXISTS tenant_id;
+CREATE INDEX IF NOT EXISTS idx_memory_relationships_source ON memory_relationships (source);
+CREATE INDEX IF NOT EXISTS idx_memory_relationships_target ON memory_relationships (target);
+
+DELETE FROM memory_entity_aliases a
+USING memory_entity_aliases b
+WHERE a.alias = b.alias AND a.tenant_id > b.tenant_id;
+DROP INDEX IF EXISTS idx_memory_entity_aliases_tenant_entity;
+ALTER TABLE memory_entity_aliases DROP CONSTRAINT IF EXISTS memory_entity_aliases_pkey;
+ALTER TABLE memory_entity_aliases DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE memory_entity_aliases ADD PRIMARY KEY (alias);
+CREATE INDEX IF NOT EXISTS idx_memory_entity_aliases_entity_name
+    ON memory_entity_aliases (entity_name);
+
+DELETE FROM memory_entities a
+USING memory_entities b
+WHERE a.name = b.name AND a.tenant_id > b.tenant_id;
+ALTER TABLE memory_entities DROP CONSTRAINT IF EXISTS memory_entities_pkey;
+ALTER TABLE memory_entities DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE memory_entities ADD PRIMARY KEY (name);
+
+DROP INDEX IF EXISTS idx_memory_mentions_tenant_entity;
+ALTER TABLE memory_mentions DROP COLUMN IF EXISTS tenant_id;
+CREATE INDEX IF NOT EXISTS idx_memory_mentions_entity_name
+    ON memory_mentions (entity_name);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000070 down] Memory graph tenants merged'; END $$;
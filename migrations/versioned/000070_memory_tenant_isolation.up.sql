@@ -0,0 +1,103 @@
+-- Migration: 000070_memory_tenant_isolation
+-- Description: Partition the memory graph (MEMORY_DRIVER=database) by tenant.
+-- Entities used to be shared by every tenant and merged by name; they are now
+-- keyed by (tenant_id, name). Each existing entity is copied once per tenant
+-- whose episodes mention it, along with its aliases and relationships, and
+-- the shared row is dropped. Mentions, profile facts and session summaries
+-- record their tenant too.
+DO $$ BEGIN RAISE NOTICE '[Migration 000070] Partitioning memory graph by tenant'; END $$;
+
+-- Mentions take the tenant of their episode.
+ALTER TABLE memory_mentions ADD COLUMN IF NOT EXISTS tenant_id BIGINT NOT NULL DEFAULT 0;
+UPDATE memory_mentions m SET tenant_id = e.tenant_id
+FROM memory_episodes e
+WHERE e.id = m.episode_id;
+DROP INDEX IF EXISTS idx_memory_mentions_entity_name;
+CREATE INDEX IF NOT EXISTS idx_memory_mentions_tenant_entity
+    ON memory_mentions (tenant_id, entity_name);
+
+-- Entities: one copy per mentioning tenant; shared rows keep a NULL tenant
+-- until they are deleted.
+ALTER TABLE memory_entities ADD COLUMN IF NOT EXISTS tenant_id BIGINT;
+ALTER TABLE memory_entities DROP CONSTRAINT IF EXISTS memory_entities_pkey;
+INSERT INTO memory_entities (tenant_id, name, type, description, embedding_model, embedding)
+SELECT DISTINCT m.tenant_id, n.name, n.type, n.description, n.embedding_model, n.embedding
+FROM memory_entities n
+JOIN memory_mentions m ON m.entity_name = n.name
+WHERE n.tenant_id IS NULL;
+DELETE FROM memory_entities WHERE tenant_id IS NULL;
+ALTER TABLE memory_entities
+    ALTER COLUMN tenant_id SET DEFAULT 0,
+    ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE memory_entities ADD PRIMARY KEY (tenant_id, name);
+
+-- Aliases follow their entity.
+ALTER TABLE memory_entity_aliases ADD COLUMN IF NOT EXISTS tenant_id BIGINT;
+ALTER TABLE memory_entity_aliases DROP CONSTRAINT IF EXISTS memory_entity_aliases_pkey;
+INSERT INTO memory_entity_aliases (tenant_id, alias, entity_name)
+SELECT n.tenant_id, a.alias, a.entity_name
+FROM memory_entity_aliases a
+JOIN memory_entities n ON n.name = a.entity_name
+WHERE a.tenant_id IS NULL;
+DELETE FROM memory_entity_aliases WHERE tenant_id IS NULL;
+ALTER TABLE memory_entity_aliases
+    ALTER COLUMN tenant_id SET DEFAULT 0,
+    ALTER COLUMN tenant_id SET NOT NULL;
+ALTER TABLE memory_entity_aliases ADD PRIMARY KEY (tenant_id, alias);
+DROP INDEX IF EXISTS idx_memory_entity_aliases_entity_name;
+CREATE INDEX IF NOT EXISTS idx_memory_entity_aliases_tenant_entity
+    ON memory_entity_aliases (tenant_id, entity_name);
+
+-- Relationships asserted by a known episode belong to its tenant; older ones
+-- are copied to every tenant that has both of their ends.
+ALTER TABLE memory_relationships ADD COLUMN IF NOT EXISTS tenant_id BIGINT;
+UPDATE memory_relationships r SET tenant_id = e.tenant_id
+FROM memory_episodes e
+WHERE e.id = r.episode_id AND r.tenant_id IS NULL;
+INSERT INTO memory_relationships (id, tenant_id, source, target, description, weight,
+    user_id, episode_id, valid_from, valid_to, invalidated_by)
+SELECT CAST(uuid_generate_v4() AS VARCHAR(36)), s.tenant_id, r.source, r.target, r.description, r.weight,
+    r.user_id, r.episode_id, r.valid_from, r.valid_to, r.invalidated_by
+FROM memory_relationships r
+JOIN memory_entities s ON s.name = r.source
+JOIN memory_entities t ON t.name = r.target AND t.tenant_id = s.tenant_id
+WHERE r.tenant_id IS NULL;
+DELETE FROM memory_relationships WHERE tenant_id IS NULL;
+ALTER TABLE memory_relationships
+    ALTER COLUMN tenant_id SET DEFAULT 0,
+    ALTER COLUMN tenant_id SET NOT NULL;
+DROP INDEX IF EXISTS idx_memory_relationships_source;
+DROP INDEX IF EXISTS idx_memory_relationships_target;
+CREATE INDEX IF NOT EXISTS idx_memory_relationships_tenant_source
+    ON memory_relationships (tenant_id, source);
+CREATE INDEX IF NOT EXISTS idx_memory_relationships_tenant_target
+    ON memory_relationships (tenant_id, target);
+
+-- Profile facts take the tenant of the user's latest episode.
+ALTER TABLE memory_profile_facts ADD COLUMN IF NOT EXISTS tenant_id BIGINT NOT NULL DEFAULT 0;
+UPDATE memory_profile_facts f SET tenant_id = COALESCE((
+    SELECT e.tenant_id FROM memory_episodes e
+    WHERE e.user_id = f.user_id
+    ORDER BY e.created_at DESC
+    LIMIT 1
+), 0);
+ALTER TABLE memory_profile_facts DROP CONSTRAINT IF EXISTS memory_profile_facts_pkey;
+ALTER TABLE memory_profile_facts ADD PRIMARY KEY (tenant_id, user_id, category, fact_key);
+
+ALTER TABLE memory_profile_fact_sources ADD COLUMN IF NOT EXISTS tenant_id BIGINT NOT NULL DEFAULT 0;
+UPDATE memory_profile_fact_sources s SET tenant_id = COALESCE((
+    SELECT e.tenant_id FROM memory_episodes e
+    WHERE e.user_id = s.user_id
+    ORDER BY e.created_at DESC
+    LIMIT 1
+), 0);
+ALTER TABLE memory_profile_fact_sources DROP CONSTRAINT IF EXISTS memory_profile_fact_sources_pkey;
+ALTER TABLE memory_profile_fact_sources ADD PRIMARY KEY (tenant_id, user_id, category, fact_key, episode_id);
+
+-- Session summaries take the tenant of their session.
+ALTER TABLE memory_session_summaries ADD COLUMN IF NOT EXISTS tenant_id BIGINT NOT NULL DEFAULT 0;
+UPDATE memory_session_summaries ss SET tenant_id = s.tenant_id
+FROM sessions s
+WHERE s.id = ss.session_id;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000070] Memory graph partitioned by tenant'; END $$;
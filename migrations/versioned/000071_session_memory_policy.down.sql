@@ -0,0 +1,7 @@
+-- Migration: 000071_session_memory_policy (down)
+-- Description: Remove the per-session memory policy.
+DO $$ BEGIN RAISE NOTICE '[Migration 000071 down] Dropping memory_policy from sessions'; END $$;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS memory_policy;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000071 down] memory_policy dropped'; END $$;
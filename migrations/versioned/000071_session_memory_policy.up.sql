@@ -0,0 +1,8 @@
+-- Migration: 000071_session_memory_policy
+-- Description: Let each session choose when its turns are stored in the
+-- user's long-term memory.
+DO $$ BEGIN RAISE NOTICE '[Migration 000071] Adding memory_policy to sessions'; END $$;
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS memory_policy JSONB;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000071] memory_policy added'; END $$;
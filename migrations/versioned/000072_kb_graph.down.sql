@@ -0,0 +1,8 @@
+-- Migration: 000072_kb_graph (down)
+-- Description: Drop the knowledge base graph tables used by GRAPH_DRIVER=database.
+DO $$ BEGIN RAISE NOTICE '[Migration 000072 down] Dropping knowledge base graph tables'; END $$;
+
+DROP TABLE IF EXISTS kb_graph_relations;
+DROP TABLE IF EXISTS kb_graph_nodes;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000072 down] Knowledge base graph tables dropped'; END $$;
@@ -0,0 +1,32 @@
+-- Migration: 000072_kb_graph
+-- Description: Store knowledge base graphs in the application database for
+-- deployments without Neo4j (GRAPH_DRIVER=database). Nodes are keyed by
+-- knowledge base, knowledge and entity name like the Neo4j labels and
+-- {name, kg} merge key; chunk IDs and attributes are JSON arrays.
+DO $$ BEGIN RAISE NOTICE '[Migration 000072] Creating knowledge base graph tables'; END $$;
+
+CREATE TABLE IF NOT EXISTS kb_graph_nodes (
+    knowledge_base_id VARCHAR(36)  NOT NULL,
+    knowledge_id      VARCHAR(36)  NOT NULL DEFAULT '',
+    name              VARCHAR(512) NOT NULL,
+    attributes        TEXT         NOT NULL DEFAULT '[]',
+    chunks            TEXT         NOT NULL DEFAULT '[]',
+    PRIMARY KEY (knowledge_base_id, knowledge_id, name)
+);
+CREATE INDEX IF NOT EXISTS idx_kb_graph_nodes_kb_name
+    ON kb_graph_nodes (knowledge_base_id, name);
+
+CREATE TABLE IF NOT EXISTS kb_graph_relations (
+    knowledge_base_id VARCHAR(36)  NOT NULL,
+    knowledge_id      VARCHAR(36)  NOT NULL DEFAULT '',
+    source            VARCHAR(512) NOT NULL,
+    target            VARCHAR(512) NOT NULL,
+    type              VARCHAR(255) NOT NULL DEFAULT '',
+    PRIMARY KEY (knowledge_base_id, knowledge_id, source, target, type)
+);
+CREATE INDEX IF NOT EXISTS idx_kb_graph_relations_kb_source
+    ON kb_graph_relations (knowledge_base_id, source);
+CREATE INDEX IF NOT EXISTS idx_kb_graph_relations_kb_target
+    ON kb_graph_relations (knowledge_base_id, target);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000072] Knowledge base graph tables ready'; END $$;
@@ -0,0 +1,7 @@
+-- Migration: 000073_session_rerank_config (down)
+-- Description: Remove the per-session rerank config.
+DO $$ BEGIN RAISE NOTICE '[Migration 000073 down] Dropping rerank_config from sessions'; END $$;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS rerank_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000073 down] rerank_config dropped'; END $$;
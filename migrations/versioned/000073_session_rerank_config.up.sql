@@ -0,0 +1,7 @@
+-- Migration: 000073_session_rerank_config
+-- Description: Let each session turn reranking on or off and set its top-N.
+DO $$ BEGIN RAISE NOTICE '[Migration 000073] Adding rerank_config to sessions'; END $$;
+
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS rerank_config JSONB;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000073] rerank_config added'; END $$;
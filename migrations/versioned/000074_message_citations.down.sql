@@ -0,0 +1,7 @@
+-- Migration: 000074_message_citations (down)
+-- Description: Remove the inline citations of messages.
+DO $$ BEGIN RAISE NOTICE '[Migration 000074 down] Dropping citations from messages'; END $$;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS citations;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000074 down] citations dropped'; END $$;
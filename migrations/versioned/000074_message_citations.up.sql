@@ -0,0 +1,7 @@
+-- Migration: 000074_message_citations
+-- Description: Store the sources an answer cites inline as [n].
+DO $$ BEGIN RAISE NOTICE '[Migration 000074] Adding citations to messages'; END $$;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS citations JSONB;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000074] citations added'; END $$;
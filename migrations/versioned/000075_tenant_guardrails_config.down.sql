@@ -0,0 +1,7 @@
+-- Migration: 000075_tenant_guardrails_config (down)
+-- Description: Remove the per-tenant guardrails config.
+DO $$ BEGIN RAISE NOTICE '[Migration 000075 down] Dropping guardrails_config from tenants'; END $$;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS guardrails_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000075 down] guardrails_config dropped'; END $$;
@@ -0,0 +1,8 @@
+-- Migration: 000075_tenant_guardrails_config
+-- Description: Let tenants filter prompt injection and PII out of the user
+-- input and retrieved chunks before generation.
+DO $$ BEGIN RAISE NOTICE '[Migration 000075] Adding guardrails_config to tenants'; END $$;
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS guardrails_config JSONB;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000075] guardrails_config added'; END $$;
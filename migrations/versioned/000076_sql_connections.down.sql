@@ -0,0 +1,7 @@
+-- Migration: 000076_sql_connections (down)
+-- Description: Drop the tenant SQL connections.
+DO $$ BEGIN RAISE NOTICE '[Migration 000076 down] Dropping sql_connections table'; END $$;
+
+DROP TABLE IF EXISTS sql_connections;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000076 down] sql_connections table dropped'; END $$;
@@ -0,0 +1,25 @@
+-- Migration: 000076_sql_connections
+-- Description: Create sql_connections for the tenant databases knowledge QA
+-- answers analytical questions from with generated read-only SQL.
+DO $$ BEGIN RAISE NOTICE '[Migration 000076] Creating sql_connections table'; END $$;
+
+-- Agents reference these by ID via custom_agents.config.sql_connection_ids
+CREATE TABLE IF NOT EXISTS sql_connections (
+    id VARCHAR(36) NOT NULL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    driver VARCHAR(50) NOT NULL,
+    parameters JSONB,
+    allowed_tables JSONB,
+    max_rows INTEGER NOT NULL DEFAULT 0,
+    query_timeout INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_sql_connections_tenant_id ON sql_connections (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_sql_connections_deleted_at ON sql_connections (deleted_at);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000076] sql_connections table created'; END $$;
@@ -0,0 +1,7 @@
+-- Migration: 000077_message_verification (down)
+-- Description: Remove the answer verification of messages.
+DO $$ BEGIN RAISE NOTICE '[Migration 000077 down] Dropping verification from messages'; END $$;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS verification;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000077 down] verification dropped'; END $$;
@@ -0,0 +1,7 @@
+-- Migration: 000077_message_verification
+-- Description: Store the verification of an answer's claims against the retrieved context.
+DO $$ BEGIN RAISE NOTICE '[Migration 000077] Adding verification to messages'; END $$;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS verification JSONB;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000077] verification added'; END $$;
@@ -0,0 +1,8 @@
+-- Migration: 000078_pipeline_config (down)
+-- Description: Remove the per-stage pipeline config from tenants and sessions.
+DO $$ BEGIN RAISE NOTICE '[Migration 000078 down] Dropping pipeline_config from tenants and sessions'; END $$;
+
+ALTER TABLE sessions DROP COLUMN IF EXISTS pipeline_config;
+ALTER TABLE tenants DROP COLUMN IF EXISTS pipeline_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000078 down] pipeline_config dropped'; END $$;
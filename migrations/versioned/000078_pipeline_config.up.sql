@@ -0,0 +1,8 @@
+-- Migration: 000078_pipeline_config
+-- Description: Store a per-stage knowledge QA pipeline config on tenants and sessions.
+DO $$ BEGIN RAISE NOTICE '[Migration 000078] Adding pipeline_config to tenants and sessions'; END $$;
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS pipeline_config JSONB;
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS pipeline_config JSONB;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000078] pipeline_config added'; END $$;
@@ -0,0 +1,7 @@
+-- Migration: 000079_message_metadata (down)
+-- Description: Remove the generation metadata of messages.
+DO $$ BEGIN RAISE NOTICE '[Migration 000079 down] Dropping metadata from messages'; END $$;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS metadata;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000079 down] metadata dropped'; END $$;
@@ -0,0 +1,7 @@
+-- Migration: 000079_message_metadata
+-- Description: Store generation metadata of messages, such as the chat model that answered.
+DO $$ BEGIN RAISE NOTICE '[Migration 000079] Adding metadata to messages'; END $$;
+
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS metadata JSONB;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000079] metadata added'; END $$;
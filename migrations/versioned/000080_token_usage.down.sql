@@ -0,0 +1,8 @@
+-- Migration: 000080_token_usage (down)
+-- Description: Remove token usage accounting and tenant token budgets.
+DO $$ BEGIN RAISE NOTICE '[Migration 000080 down] Dropping token_usage_daily table'; END $$;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS token_budget;
+DROP TABLE IF EXISTS token_usage_daily;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000080 down] token_usage_daily table dropped'; END $$;
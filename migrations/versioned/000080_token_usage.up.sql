@@ -0,0 +1,23 @@
+-- Migration: 000080_token_usage
+-- Description: Aggregate model token usage per tenant, day, model and session,
+-- and store an optional token budget on tenants.
+DO $$ BEGIN RAISE NOTICE '[Migration 000080] Creating token_usage_daily table'; END $$;
+
+CREATE TABLE IF NOT EXISTS token_usage_daily (
+    tenant_id BIGINT NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    model_id VARCHAR(64) NOT NULL DEFAULT '',
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    model_name VARCHAR(255) NOT NULL DEFAULT '',
+    requests BIGINT NOT NULL DEFAULT 0,
+    estimated_requests BIGINT NOT NULL DEFAULT 0,
+    prompt_tokens BIGINT NOT NULL DEFAULT 0,
+    completion_tokens BIGINT NOT NULL DEFAULT 0,
+    embedding_tokens BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (tenant_id, day, model_id, session_id)
+);
+
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS token_budget JSONB;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000080] token_usage_daily table created'; END $$;
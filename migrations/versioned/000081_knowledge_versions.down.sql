@@ -0,0 +1,7 @@
+-- Migration: 000081_knowledge_versions (down)
+-- Description: Remove knowledge file versions.
+DO $$ BEGIN RAISE NOTICE '[Migration 000081 down] Dropping knowledge_versions table'; END $$;
+
+DROP TABLE IF EXISTS knowledge_versions;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000081 down] knowledge_versions table dropped'; END $$;
@@ -0,0 +1,24 @@
+-- Migration: 000081_knowledge_versions
+-- Description: Record the file versions of knowledge updated in place, with
+-- the chunks added, reused and removed by each version.
+DO $$ BEGIN RAISE NOTICE '[Migration 000081] Creating knowledge_versions table'; END $$;
+
+CREATE TABLE IF NOT EXISTS knowledge_versions (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    version INTEGER NOT NULL,
+    file_name VARCHAR(255) NOT NULL DEFAULT '',
+    file_type VARCHAR(50) NOT NULL DEFAULT '',
+    file_size BIGINT NOT NULL DEFAULT 0,
+    file_hash VARCHAR(64) NOT NULL DEFAULT '',
+    chunks_added INTEGER NOT NULL DEFAULT 0,
+    chunks_reused INTEGER NOT NULL DEFAULT 0,
+    chunks_removed INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_knowledge_versions_knowledge_version
+    ON knowledge_versions (tenant_id, knowledge_id, version);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000081] knowledge_versions table created'; END $$;
@@ -0,0 +1,9 @@
+-- Migration: 000082_kb_snapshots (down)
+-- Description: Remove knowledge base snapshots and snapshot policies. The
+-- archive parts in object storage are left in place.
+DO $$ BEGIN RAISE NOTICE '[Migration 000082 down] Dropping kb_snapshots and kb_snapshot_policies tables'; END $$;
+
+DROP TABLE IF EXISTS kb_snapshot_policies;
+DROP TABLE IF EXISTS kb_snapshots;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000082 down] kb_snapshots and kb_snapshot_policies tables dropped'; END $$;
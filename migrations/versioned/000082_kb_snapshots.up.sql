@@ -0,0 +1,42 @@
+-- Migration: 000082_kb_snapshots
+-- Description: Knowledge base snapshots stored in object storage and the
+-- per knowledge base policies that schedule them.
+DO $$ BEGIN RAISE NOTICE '[Migration 000082] Creating kb_snapshots and kb_snapshot_policies tables'; END $$;
+
+CREATE TABLE IF NOT EXISTS kb_snapshots (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    knowledge_base_name VARCHAR(255) NOT NULL DEFAULT '',
+    trigger VARCHAR(16) NOT NULL DEFAULT 'manual',
+    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+    parts JSONB,
+    size BIGINT NOT NULL DEFAULT 0,
+    knowledge_count INTEGER NOT NULL DEFAULT 0,
+    chunk_count INTEGER NOT NULL DEFAULT 0,
+    vector_count INTEGER NOT NULL DEFAULT 0,
+    vectors_included BOOLEAN NOT NULL DEFAULT FALSE,
+    embedding_model_id VARCHAR(64) NOT NULL DEFAULT '',
+    error_message TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_kb_snapshots_tenant_kb
+    ON kb_snapshots (tenant_id, knowledge_base_id, created_at);
+
+CREATE TABLE IF NOT EXISTS kb_snapshot_policies (
+    knowledge_base_id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    interval_hours INTEGER NOT NULL DEFAULT 24,
+    retain INTEGER NOT NULL DEFAULT 7,
+    next_run_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_kb_snapshot_policies_due
+    ON kb_snapshot_policies (enabled, next_run_at);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000082] kb_snapshots and kb_snapshot_policies tables created'; END $$;
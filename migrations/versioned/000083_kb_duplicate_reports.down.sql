@@ -0,0 +1,7 @@
+-- Migration: 000083_kb_duplicate_reports (down)
+-- Description: Remove knowledge base duplicate content reports.
+DO $$ BEGIN RAISE NOTICE '[Migration 000083 down] Dropping kb_duplicate_reports table'; END $$;
+
+DROP TABLE IF EXISTS kb_duplicate_reports;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000083 down] kb_duplicate_reports table dropped'; END $$;
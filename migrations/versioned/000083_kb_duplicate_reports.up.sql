@@ -0,0 +1,27 @@
+-- Migration: 000083_kb_duplicate_reports
+-- Description: Latest exact / near duplicate content report of each
+-- knowledge base.
+DO $$ BEGIN RAISE NOTICE '[Migration 000083] Creating kb_duplicate_reports table'; END $$;
+
+CREATE TABLE IF NOT EXISTS kb_duplicate_reports (
+    knowledge_base_id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    status VARCHAR(16) NOT NULL DEFAULT 'running',
+    threshold DOUBLE PRECISION NOT NULL DEFAULT 0.9,
+    chunk_count INTEGER NOT NULL DEFAULT 0,
+    document_count INTEGER NOT NULL DEFAULT 0,
+    truncated BOOLEAN NOT NULL DEFAULT FALSE,
+    document_groups JSONB,
+    chunk_groups JSONB,
+    redundant_document_count INTEGER NOT NULL DEFAULT 0,
+    redundant_chunk_count INTEGER NOT NULL DEFAULT 0,
+    error_message TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    completed_at TIMESTAMP,
+    removed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_kb_duplicate_reports_tenant
+    ON kb_duplicate_reports (tenant_id);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000083] kb_duplicate_reports table created'; END $$;
@@ -0,0 +1,8 @@
+-- Migration: 000084_auto_tagging (down)
+-- Description: Remove the auto-tagging columns.
+DO $$ BEGIN RAISE NOTICE '[Migration 000084 down] Dropping auto-tagging columns'; END $$;
+
+ALTER TABLE knowledge_tag_relations DROP COLUMN IF EXISTS source;
+ALTER TABLE knowledge_bases DROP COLUMN IF EXISTS auto_tag_config;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000084 down] Auto-tagging columns dropped'; END $$;
@@ -0,0 +1,12 @@
+-- Migration: 000084_auto_tagging
+-- Description: Auto-tagging configuration on knowledge bases and the source
+-- (manual / auto) of each document tag relation.
+DO $$ BEGIN RAISE NOTICE '[Migration 000084] Adding auto-tagging columns'; END $$;
+
+ALTER TABLE knowledge_bases
+    ADD COLUMN IF NOT EXISTS auto_tag_config JSONB;
+
+ALTER TABLE knowledge_tag_relations
+    ADD COLUMN IF NOT EXISTS source VARCHAR(16) NOT NULL DEFAULT 'manual';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000084] Auto-tagging columns added'; END $$;
@@ -0,0 +1,9 @@
+-- Migration: 000085_tag_hierarchy_access (down)
+-- Description: Remove tag access rules and the tag hierarchy.
+DO $$ BEGIN RAISE NOTICE '[Migration 000085 down] Dropping tag hierarchy and tag access rules'; END $$;
+
+DROP TABLE IF EXISTS tag_access_rules;
+DROP INDEX IF EXISTS idx_knowledge_tags_parent;
+ALTER TABLE knowledge_tags DROP COLUMN IF EXISTS parent_id;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000085 down] Tag hierarchy and tag access rules dropped'; END $$;
@@ -0,0 +1,26 @@
+-- Migration: 000085_tag_hierarchy_access
+-- Description: Parent / child tag hierarchy and per-subject tag access rules
+-- restricting retrieval to tag subtrees.
+DO $$ BEGIN RAISE NOTICE '[Migration 000085] Adding tag hierarchy and tag access rules'; END $$;
+
+ALTER TABLE knowledge_tags
+    ADD COLUMN IF NOT EXISTS parent_id VARCHAR(36) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_knowledge_tags_parent
+    ON knowledge_tags (parent_id);
+
+CREATE TABLE IF NOT EXISTS tag_access_rules (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    subject_type VARCHAR(16) NOT NULL,
+    subject_id VARCHAR(64) NOT NULL DEFAULT '',
+    tag_ids JSONB,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tag_access_rules_subject
+    ON tag_access_rules (tenant_id, knowledge_base_id, subject_type, subject_id);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000085] Tag hierarchy and tag access rules added'; END $$;
@@ -0,0 +1,7 @@
+-- Migration: 000086_api_key_role (down)
+-- Description: Remove the API key role of tenants.
+DO $$ BEGIN RAISE NOTICE '[Migration 000086 down] Dropping tenants.api_key_role'; END $$;
+
+ALTER TABLE tenants DROP COLUMN IF EXISTS api_key_role;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000086 down] tenants.api_key_role dropped'; END $$;
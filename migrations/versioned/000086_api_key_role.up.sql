@@ -0,0 +1,9 @@
+-- Migration: 000086_api_key_role
+-- Description: Tenant role granted to requests authenticated with the
+-- tenant API key. Existing tenants keep the previous fixed admin role.
+DO $$ BEGIN RAISE NOTICE '[Migration 000086] Adding tenants.api_key_role'; END $$;
+
+ALTER TABLE tenants
+    ADD COLUMN IF NOT EXISTS api_key_role VARCHAR(32) NOT NULL DEFAULT 'admin';
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000086] tenants.api_key_role added'; END $$;
@@ -0,0 +1,7 @@
+-- Migration: 000087_user_sso_groups (down)
+-- Description: Remove the external groups of SSO users.
+DO $$ BEGIN RAISE NOTICE '[Migration 000087 down] Dropping users.sso_groups'; END $$;
+
+ALTER TABLE users DROP COLUMN IF EXISTS sso_groups;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000087 down] users.sso_groups dropped'; END $$;
@@ -0,0 +1,10 @@
+-- Migration: 000087_user_sso_groups
+-- Description: External groups of SSO users, refreshed from the identity
+-- provider's group claim on every OIDC login. Document ACLs match them
+-- as "sso:<group>".
+DO $$ BEGIN RAISE NOTICE '[Migration 000087] Adding users.sso_groups'; END $$;
+
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS sso_groups JSONB;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000087] users.sso_groups added'; END $$;
@@ -0,0 +1,7 @@
+-- Migration: 000088_api_keys (down)
+-- Description: Drop managed API keys.
+DO $$ BEGIN RAISE NOTICE '[Migration 000088 down] Dropping api_keys'; END $$;
+
+DROP TABLE IF EXISTS api_keys;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000088 down] api_keys dropped'; END $$;
@@ -0,0 +1,27 @@
+-- Migration: 000088_api_keys
+-- Description: Managed API keys with scopes, knowledge base restriction,
+-- expiry and last-used tracking. Only the SHA-256 of each secret is stored.
+DO $$ BEGIN RAISE NOTICE '[Migration 000088] Creating api_keys'; END $$;
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(100) NOT NULL DEFAULT '',
+    key_prefix VARCHAR(16) NOT NULL DEFAULT '',
+    key_hash VARCHAR(64) NOT NULL,
+    role VARCHAR(32) NOT NULL DEFAULT 'viewer',
+    scopes JSONB,
+    knowledge_base_ids JSONB,
+    expires_at TIMESTAMP,
+    last_used_at TIMESTAMP,
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_id ON api_keys (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_deleted_at ON api_keys (deleted_at);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000088] api_keys created'; END $$;
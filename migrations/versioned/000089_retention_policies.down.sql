@@ -0,0 +1,7 @@
+-- Migration: 000089_retention_policies (down)
+-- Description: Drop data retention policies.
+DO $$ BEGIN RAISE NOTICE '[Migration 000089 down] Dropping retention_policies'; END $$;
+
+DROP TABLE IF EXISTS retention_policies;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000089 down] retention_policies dropped'; END $$;
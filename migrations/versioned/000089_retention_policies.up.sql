@@ -0,0 +1,25 @@
+-- Migration: 000089_retention_policies
+-- Description: Data retention policies. One tenant-wide policy per tenant
+-- (empty knowledge_base_id) ages out chat sessions, messages and memory
+-- episodes; one policy per knowledge base expires its documents.
+DO $$ BEGIN RAISE NOTICE '[Migration 000089] Creating retention_policies'; END $$;
+
+CREATE TABLE IF NOT EXISTS retention_policies (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    enabled BOOLEAN NOT NULL DEFAULT FALSE,
+    session_retention_days INTEGER NOT NULL DEFAULT 0,
+    memory_retention_days INTEGER NOT NULL DEFAULT 0,
+    knowledge_retention_days INTEGER NOT NULL DEFAULT 0,
+    knowledge_expires_at TIMESTAMP,
+    next_run_at TIMESTAMP,
+    last_run_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_retention_policies_scope ON retention_policies (tenant_id, knowledge_base_id);
+CREATE INDEX IF NOT EXISTS idx_retention_policies_next_run_at ON retention_policies (next_run_at);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000089] retention_policies created'; END $$;
@@ -0,0 +1,10 @@
+-- Migration: 000090_retrieval_eval (down)
+-- Description: Drop the retrieval evaluation tables.
+DO $$ BEGIN RAISE NOTICE '[Migration 000090 down] Dropping retrieval evaluation tables'; END $$;
+
+DROP TABLE IF EXISTS eval_run_results;
+DROP TABLE IF EXISTS eval_runs;
+DROP TABLE IF EXISTS eval_dataset_items;
+DROP TABLE IF EXISTS eval_datasets;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000090 down] Retrieval evaluation tables dropped'; END $$;
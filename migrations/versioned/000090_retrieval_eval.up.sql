@@ -0,0 +1,71 @@
+-- Migration: 000090_retrieval_eval
+-- Description: Retrieval evaluation harness. Golden datasets of questions
+-- with expected sources, runs of a dataset against a knowledge base with
+-- the retrieval configuration under test, and the per-question results.
+DO $$ BEGIN RAISE NOTICE '[Migration 000090] Creating retrieval evaluation tables'; END $$;
+
+CREATE TABLE IF NOT EXISTS eval_datasets (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    item_count INTEGER NOT NULL DEFAULT 0,
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_eval_datasets_tenant_id ON eval_datasets (tenant_id);
+
+CREATE TABLE IF NOT EXISTS eval_dataset_items (
+    id VARCHAR(36) PRIMARY KEY,
+    dataset_id VARCHAR(36) NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    question TEXT NOT NULL,
+    expected_answer TEXT NOT NULL DEFAULT '',
+    expected_sources JSONB NOT NULL DEFAULT '[]'
+);
+
+CREATE INDEX IF NOT EXISTS idx_eval_dataset_items_dataset_id ON eval_dataset_items (dataset_id, position);
+
+CREATE TABLE IF NOT EXISTS eval_runs (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    dataset_id VARCHAR(36) NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    status VARCHAR(32) NOT NULL DEFAULT 'pending',
+    config JSONB NOT NULL DEFAULT '{}',
+    metrics JSONB,
+    total INTEGER NOT NULL DEFAULT 0,
+    finished INTEGER NOT NULL DEFAULT 0,
+    failed INTEGER NOT NULL DEFAULT 0,
+    err_msg TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    started_at TIMESTAMP,
+    finished_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_eval_runs_tenant_id ON eval_runs (tenant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_eval_runs_dataset_id ON eval_runs (dataset_id);
+
+CREATE TABLE IF NOT EXISTS eval_run_results (
+    id VARCHAR(36) PRIMARY KEY,
+    run_id VARCHAR(36) NOT NULL,
+    item_id VARCHAR(36) NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0,
+    question TEXT NOT NULL DEFAULT '',
+    expected_sources INTEGER NOT NULL DEFAULT 0,
+    retrieved JSONB NOT NULL DEFAULT '[]',
+    metrics JSONB NOT NULL DEFAULT '{}',
+    answer TEXT NOT NULL DEFAULT '',
+    judge_reason TEXT NOT NULL DEFAULT '',
+    err_msg TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_eval_run_results_run_id ON eval_run_results (run_id, position);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000090] Retrieval evaluation tables created'; END $$;
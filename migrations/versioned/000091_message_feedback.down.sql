@@ -0,0 +1,8 @@
+-- Migration: 000091_message_feedback (down)
+-- Description: Drop the message feedback tables.
+DO $$ BEGIN RAISE NOTICE '[Migration 000091 down] Dropping message feedback tables'; END $$;
+
+DROP TABLE IF EXISTS message_feedback_sources;
+DROP TABLE IF EXISTS message_feedbacks;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000091 down] Message feedback tables dropped'; END $$;
@@ -0,0 +1,47 @@
+-- Migration: 000091_message_feedback
+-- Description: Feedback on answers. One feedback per user and message with
+-- a rating and a snapshot of the question, the answer and the retrieved
+-- sources, each marked as cited and as wrong, for stats and exports.
+DO $$ BEGIN RAISE NOTICE '[Migration 000091] Creating message feedback tables'; END $$;
+
+CREATE TABLE IF NOT EXISTS message_feedbacks (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    session_id VARCHAR(36) NOT NULL,
+    message_id VARCHAR(36) NOT NULL,
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    rating VARCHAR(16) NOT NULL DEFAULT '',
+    comment TEXT NOT NULL DEFAULT '',
+    wrong_sources INTEGER NOT NULL DEFAULT 0,
+    day VARCHAR(10) NOT NULL,
+    question TEXT NOT NULL DEFAULT '',
+    answer TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_message_feedback_user ON message_feedbacks (message_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_message_feedbacks_tenant_id ON message_feedbacks (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_message_feedbacks_day ON message_feedbacks (day);
+
+CREATE TABLE IF NOT EXISTS message_feedback_sources (
+    feedback_id VARCHAR(36) NOT NULL,
+    position INTEGER NOT NULL,
+    tenant_id BIGINT NOT NULL,
+    chunk_id VARCHAR(64) NOT NULL DEFAULT '',
+    knowledge_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_title TEXT NOT NULL DEFAULT '',
+    content TEXT NOT NULL DEFAULT '',
+    score DOUBLE PRECISION NOT NULL DEFAULT 0,
+    score_bucket INTEGER NOT NULL DEFAULT 0,
+    cited BOOLEAN NOT NULL DEFAULT FALSE,
+    wrong BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (feedback_id, position)
+);
+
+CREATE INDEX IF NOT EXISTS idx_message_feedback_sources_tenant_id ON message_feedback_sources (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_message_feedback_sources_chunk_id ON message_feedback_sources (chunk_id);
+CREATE INDEX IF NOT EXISTS idx_message_feedback_sources_knowledge_base_id ON message_feedback_sources (knowledge_base_id);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000091] Message feedback tables created'; END $$;
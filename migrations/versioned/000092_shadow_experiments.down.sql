@@ -0,0 +1,8 @@
+-- Migration: 000092_shadow_experiments (down)
+-- Description: Drop the shadow experiment tables.
+DO $$ BEGIN RAISE NOTICE '[Migration 000092 down] Dropping shadow experiment tables'; END $$;
+
+DROP TABLE IF EXISTS experiment_samples;
+DROP TABLE IF EXISTS experiments;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000092 down] Shadow experiment tables dropped'; END $$;
@@ -0,0 +1,49 @@
+-- Migration: 000092_shadow_experiments
+-- Description: Shadow experiments. An experiment answers a sample of
+-- production questions again with an alternative pipeline configuration and
+-- stores both answers, their sources and the judge's verdict per sample.
+DO $$ BEGIN RAISE NOTICE '[Migration 000092] Creating shadow experiment tables'; END $$;
+
+CREATE TABLE IF NOT EXISTS experiments (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    knowledge_base_ids JSONB NOT NULL DEFAULT '[]',
+    sample_percent DOUBLE PRECISION NOT NULL DEFAULT 0,
+    max_samples INTEGER NOT NULL DEFAULT 0,
+    sampled INTEGER NOT NULL DEFAULT 0,
+    status VARCHAR(32) NOT NULL DEFAULT 'running',
+    config JSONB NOT NULL DEFAULT '{}',
+    created_by VARCHAR(36) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_experiments_tenant_id ON experiments (tenant_id, created_at);
+
+CREATE TABLE IF NOT EXISTS experiment_samples (
+    id VARCHAR(36) PRIMARY KEY,
+    experiment_id VARCHAR(36) NOT NULL,
+    tenant_id BIGINT NOT NULL,
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    message_id VARCHAR(36) NOT NULL DEFAULT '',
+    question TEXT NOT NULL DEFAULT '',
+    production_answer TEXT NOT NULL DEFAULT '',
+    shadow_answer TEXT NOT NULL DEFAULT '',
+    production_sources JSONB NOT NULL DEFAULT '[]',
+    shadow_sources JSONB NOT NULL DEFAULT '[]',
+    source_overlap DOUBLE PRECISION NOT NULL DEFAULT 0,
+    shadow_latency_ms BIGINT NOT NULL DEFAULT 0,
+    winner VARCHAR(16) NOT NULL DEFAULT '',
+    judge_reason TEXT NOT NULL DEFAULT '',
+    err_msg TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_experiment_samples_experiment_id ON experiment_samples (experiment_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_experiment_samples_tenant_id ON experiment_samples (tenant_id);
+CREATE INDEX IF NOT EXISTS idx_experiment_samples_session_id ON experiment_samples (session_id);
+CREATE INDEX IF NOT EXISTS idx_experiment_samples_message_id ON experiment_samples (message_id);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000092] Shadow experiment tables created'; END $$;
@@ -0,0 +1,9 @@
+-- Migration: 000093_query_analytics (down)
+-- Description: Drop the query analytics tables.
+DO $$ BEGIN RAISE NOTICE '[Migration 000093 down] Dropping query analytics tables'; END $$;
+
+DROP TABLE IF EXISTS query_log_documents;
+DROP TABLE IF EXISTS query_log_knowledge_bases;
+DROP TABLE IF EXISTS query_logs;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000093 down] Query analytics tables dropped'; END $$;
@@ -0,0 +1,57 @@
+-- Migration: 000093_query_analytics
+-- Description: Query logs for analytics. One log per answered chat query
+-- with its latency and whether it retrieved anything, plus the knowledge
+-- bases it searched and the documents it retrieved and cited.
+DO $$ BEGIN RAISE NOTICE '[Migration 000093] Creating query analytics tables'; END $$;
+
+CREATE TABLE IF NOT EXISTS query_logs (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    session_id VARCHAR(36) NOT NULL DEFAULT '',
+    message_id VARCHAR(36) NOT NULL DEFAULT '',
+    user_id VARCHAR(36) NOT NULL DEFAULT '',
+    channel VARCHAR(50) NOT NULL DEFAULT '',
+    mode VARCHAR(16) NOT NULL DEFAULT '',
+    query TEXT NOT NULL DEFAULT '',
+    query_hash VARCHAR(64) NOT NULL DEFAULT '',
+    day VARCHAR(10) NOT NULL,
+    searched BOOLEAN NOT NULL DEFAULT FALSE,
+    retrieved INTEGER NOT NULL DEFAULT 0,
+    zero_result BOOLEAN NOT NULL DEFAULT FALSE,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_query_logs_tenant_day ON query_logs (tenant_id, day);
+CREATE INDEX IF NOT EXISTS idx_query_logs_query_hash ON query_logs (query_hash);
+CREATE INDEX IF NOT EXISTS idx_query_logs_session_id ON query_logs (session_id);
+CREATE INDEX IF NOT EXISTS idx_query_logs_message_id ON query_logs (message_id);
+
+CREATE TABLE IF NOT EXISTS query_log_knowledge_bases (
+    query_log_id VARCHAR(36) NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    tenant_id BIGINT NOT NULL,
+    day VARCHAR(10) NOT NULL,
+    zero_result BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (query_log_id, knowledge_base_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_query_log_knowledge_bases_tenant_day ON query_log_knowledge_bases (tenant_id, day);
+CREATE INDEX IF NOT EXISTS idx_query_log_knowledge_bases_knowledge_base_id ON query_log_knowledge_bases (knowledge_base_id);
+
+CREATE TABLE IF NOT EXISTS query_log_documents (
+    query_log_id VARCHAR(36) NOT NULL,
+    knowledge_id VARCHAR(36) NOT NULL,
+    tenant_id BIGINT NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL DEFAULT '',
+    knowledge_title TEXT NOT NULL DEFAULT '',
+    day VARCHAR(10) NOT NULL,
+    chunks INTEGER NOT NULL DEFAULT 0,
+    cited BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (query_log_id, knowledge_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_query_log_documents_tenant_day ON query_log_documents (tenant_id, day);
+CREATE INDEX IF NOT EXISTS idx_query_log_documents_knowledge_id ON query_log_documents (knowledge_id);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000093] Query analytics tables created'; END $$;
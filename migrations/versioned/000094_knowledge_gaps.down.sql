@@ -0,0 +1,7 @@
+-- Migration: 000094_knowledge_gaps (down)
+-- Description: Drop the knowledge_gaps table.
+DO $$ BEGIN RAISE NOTICE '[Migration 000094 down] Dropping knowledge_gaps table'; END $$;
+
+DROP TABLE IF EXISTS knowledge_gaps;
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000094 down] knowledge_gaps table dropped'; END $$;
@@ -0,0 +1,24 @@
+-- Migration: 000094_knowledge_gaps
+-- Description: Knowledge gaps. A query that searched a knowledge base and
+-- retrieved nothing is recorded once per knowledge base and normalized
+-- query, counting how often it was asked; the query embedding is kept for
+-- clustering similar gaps in reports.
+DO $$ BEGIN RAISE NOTICE '[Migration 000094] Creating knowledge_gaps table'; END $$;
+
+CREATE TABLE IF NOT EXISTS knowledge_gaps (
+    id VARCHAR(36) PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    knowledge_base_id VARCHAR(36) NOT NULL,
+    query_hash VARCHAR(64) NOT NULL,
+    query TEXT NOT NULL DEFAULT '',
+    count BIGINT NOT NULL DEFAULT 0,
+    first_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_seen_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    embedding JSONB,
+    embedding_model_id VARCHAR(64) NOT NULL DEFAULT ''
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_knowledge_gaps_query ON knowledge_gaps (tenant_id, knowledge_base_id, query_hash);
+CREATE INDEX IF NOT EXISTS idx_knowledge_gaps_last_seen_at ON knowledge_gaps (last_seen_at);
+
+DO $$ BEGIN RAISE NOTICE '[Migration 000094] knowledge_gaps table created'; END $$;